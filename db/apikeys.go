@@ -0,0 +1,148 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/auth"
+)
+
+// CreateAPIKey stores an API key under the hash of its secret
+func (r *Repository) CreateAPIKey(ctx context.Context, key *auth.APIKey, secretHash string) (*auth.APIKey, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.CreateAPIKey")
+	defer span.End()
+
+	scopes, err := json.Marshal(key.Scopes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal API key scopes: %w", err)
+	}
+
+	params := sqlc.CreateAPIKeyParams{
+		ID:          key.ID,
+		Name:        key.Name,
+		SecretHash:  secretHash,
+		SecretHint:  key.SecretHint,
+		Scopes:      scopes,
+		TenantID:    key.TenantID,
+		CreatedBy:   key.CreatedBy,
+		RotatedFrom: key.RotatedFrom,
+	}
+
+	dbKey, err := r.queries.CreateAPIKey(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	return convertAPIKey(dbKey), nil
+}
+
+// GetAPIKey retrieves an API key by ID
+func (r *Repository) GetAPIKey(ctx context.Context, id string) (*auth.APIKey, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetAPIKey")
+	defer span.End()
+
+	dbKey, err := r.queries.GetAPIKey(ctx, r.db, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	return convertAPIKey(dbKey), nil
+}
+
+// GetAPIKeyBySecretHash retrieves an API key by the hash of its secret
+func (r *Repository) GetAPIKeyBySecretHash(ctx context.Context, secretHash string) (*auth.APIKey, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetAPIKeyBySecretHash")
+	defer span.End()
+
+	dbKey, err := r.queries.GetAPIKeyBySecretHash(ctx, r.db, secretHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	return convertAPIKey(dbKey), nil
+}
+
+// ListAPIKeys retrieves every API key, newest first
+func (r *Repository) ListAPIKeys(ctx context.Context) ([]*auth.APIKey, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListAPIKeys")
+	defer span.End()
+
+	dbKeys, err := r.queries.ListAPIKeys(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+
+	keys := make([]*auth.APIKey, len(dbKeys))
+	for i, dbKey := range dbKeys {
+		keys[i] = convertAPIKey(dbKey)
+	}
+
+	return keys, nil
+}
+
+// ExpireAPIKey sets when an API key stops working, reporting whether an
+// unrevoked key was updated
+func (r *Repository) ExpireAPIKey(ctx context.Context, id string, expiresAt time.Time) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ExpireAPIKey")
+	defer span.End()
+
+	params := sqlc.ExpireAPIKeyParams{
+		ID:        id,
+		ExpiresAt: sql.NullTime{Time: expiresAt, Valid: true},
+	}
+
+	rows, err := r.queries.ExpireAPIKey(ctx, r.db, params)
+	if err != nil {
+		return false, fmt.Errorf("failed to expire API key: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// RevokeAPIKey revokes an API key, reporting whether an unrevoked key was revoked
+func (r *Repository) RevokeAPIKey(ctx context.Context, id string, revokedAt time.Time) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.RevokeAPIKey")
+	defer span.End()
+
+	params := sqlc.RevokeAPIKeyParams{
+		ID:        id,
+		RevokedAt: sql.NullTime{Time: revokedAt, Valid: true},
+	}
+
+	rows, err := r.queries.RevokeAPIKey(ctx, r.db, params)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke API key: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// convertAPIKey converts a database API key to a service API key
+func convertAPIKey(dbKey sqlc.ApiKey) *auth.APIKey {
+	key := &auth.APIKey{
+		ID:          dbKey.ID,
+		Name:        dbKey.Name,
+		SecretHint:  dbKey.SecretHint,
+		TenantID:    dbKey.TenantID,
+		CreatedBy:   dbKey.CreatedBy,
+		RotatedFrom: dbKey.RotatedFrom,
+		CreatedAt:   dbKey.CreatedAt.Time,
+	}
+
+	_ = json.Unmarshal(dbKey.Scopes, &key.Scopes)
+
+	if dbKey.ExpiresAt.Valid {
+		expiresAt := dbKey.ExpiresAt.Time
+		key.ExpiresAt = &expiresAt
+	}
+	if dbKey.RevokedAt.Valid {
+		revokedAt := dbKey.RevokedAt.Time
+		key.RevokedAt = &revokedAt
+	}
+
+	return key
+}
@@ -0,0 +1,141 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/authorizations"
+)
+
+// TrackAuthorization stores a new open authorization, returning false if the
+// charge is already tracked
+func (r *Repository) TrackAuthorization(ctx context.Context, authorization *authorizations.Authorization) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.TrackAuthorization")
+	defer span.End()
+
+	rows, err := r.queries.TrackAuthorization(ctx, r.db, sqlc.TrackAuthorizationParams{
+		ChargeID:     authorization.ChargeID,
+		TenantID:     authorization.TenantID,
+		Provider:     authorization.Provider,
+		Amount:       authorization.Amount,
+		Currency:     authorization.Currency,
+		MultiCapture: authorization.MultiCapture,
+		Status:       authorization.Status,
+		ExpiresAt:    authorization.ExpiresAt,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to track authorization: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// GetAuthorization retrieves the authorization of a charge
+func (r *Repository) GetAuthorization(ctx context.Context, chargeID string) (*authorizations.Authorization, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetAuthorization")
+	defer span.End()
+
+	dbAuthorization, err := r.queries.GetAuthorization(ctx, r.db, sqlc.GetAuthorizationParams{
+		ChargeID: chargeID,
+		TenantID: scopedTenant(ctx),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get authorization: %w", err)
+	}
+
+	return convertAuthorization(dbAuthorization), nil
+}
+
+// ListAuthorizations retrieves authorizations, newest first
+func (r *Repository) ListAuthorizations(ctx context.Context, filter authorizations.Filter) ([]*authorizations.Authorization, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListAuthorizations")
+	defer span.End()
+
+	dbAuthorizations, err := r.queries.ListAuthorizations(ctx, r.db, sqlc.ListAuthorizationsParams{
+		TenantID: filter.TenantID,
+		Status:   filter.Status,
+		Limit:    int32(filter.Limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list authorizations: %w", err)
+	}
+
+	return convertAuthorizations(dbAuthorizations), nil
+}
+
+// ListStaleAuthorizations retrieves open authorizations expiring before a
+// time, soonest first
+func (r *Repository) ListStaleAuthorizations(ctx context.Context, expiringBefore time.Time, limit int) ([]*authorizations.Authorization, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListStaleAuthorizations")
+	defer span.End()
+
+	dbAuthorizations, err := r.queries.ListStaleAuthorizations(ctx, r.db, sqlc.ListStaleAuthorizationsParams{
+		ExpiresAt: expiringBefore,
+		Limit:     int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stale authorizations: %w", err)
+	}
+
+	return convertAuthorizations(dbAuthorizations), nil
+}
+
+// UpdateAuthorization stores the captures and status of an open
+// authorization. Closed authorizations are left as they are and return
+// sql.ErrNoRows.
+func (r *Repository) UpdateAuthorization(ctx context.Context, authorization *authorizations.Authorization) (*authorizations.Authorization, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.UpdateAuthorization")
+	defer span.End()
+
+	params := sqlc.UpdateAuthorizationParams{
+		ChargeID:       authorization.ChargeID,
+		AmountCaptured: authorization.AmountCaptured,
+		Captures:       int32(authorization.Captures),
+		Status:         authorization.Status,
+	}
+	if authorization.ClosedAt != nil {
+		params.ClosedAt = sql.NullTime{Time: *authorization.ClosedAt, Valid: true}
+	}
+
+	dbAuthorization, err := r.queries.UpdateAuthorization(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update authorization: %w", err)
+	}
+
+	return convertAuthorization(dbAuthorization), nil
+}
+
+func convertAuthorizations(dbAuthorizations []sqlc.Authorization) []*authorizations.Authorization {
+	converted := make([]*authorizations.Authorization, len(dbAuthorizations))
+	for i, dbAuthorization := range dbAuthorizations {
+		converted[i] = convertAuthorization(dbAuthorization)
+	}
+	return converted
+}
+
+func convertAuthorization(dbAuthorization sqlc.Authorization) *authorizations.Authorization {
+	authorization := &authorizations.Authorization{
+		ChargeID:       dbAuthorization.ChargeID,
+		TenantID:       dbAuthorization.TenantID,
+		Provider:       dbAuthorization.Provider,
+		Amount:         dbAuthorization.Amount,
+		AmountCaptured: dbAuthorization.AmountCaptured,
+		Currency:       dbAuthorization.Currency,
+		Captures:       int(dbAuthorization.Captures),
+		MultiCapture:   dbAuthorization.MultiCapture,
+		Status:         dbAuthorization.Status,
+		ExpiresAt:      dbAuthorization.ExpiresAt,
+		CreatedAt:      dbAuthorization.CreatedAt.Time,
+		UpdatedAt:      dbAuthorization.UpdatedAt.Time,
+	}
+
+	if dbAuthorization.ClosedAt.Valid {
+		closedAt := dbAuthorization.ClosedAt.Time
+		authorization.ClosedAt = &closedAt
+	}
+
+	return authorization
+}
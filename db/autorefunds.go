@@ -0,0 +1,205 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/autorefund"
+)
+
+// GetUnclaimedBalance retrieves a customer's unclaimed balance in one currency
+func (r *Repository) GetUnclaimedBalance(ctx context.Context, customerID, currency string) (*autorefund.Balance, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetUnclaimedBalance")
+	defer span.End()
+
+	dbBalance, err := r.queries.GetUnclaimedBalance(ctx, r.db, sqlc.GetUnclaimedBalanceParams{CustomerID: customerID, Currency: currency})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unclaimed balance: %w", err)
+	}
+
+	return convertUnclaimedBalance(dbBalance), nil
+}
+
+// UpsertUnclaimedBalance creates or replaces a customer's unclaimed balance
+func (r *Repository) UpsertUnclaimedBalance(ctx context.Context, balance *autorefund.Balance) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.UpsertUnclaimedBalance")
+	defer span.End()
+
+	params := sqlc.UpsertUnclaimedBalanceParams{
+		CustomerID: balance.CustomerID,
+		Currency:   balance.Currency,
+		Amount:     balance.Amount,
+	}
+	if balance.FundedAt != nil {
+		params.FundedAt = sql.NullTime{Time: *balance.FundedAt, Valid: true}
+	}
+
+	if err := r.queries.UpsertUnclaimedBalance(ctx, r.db, params); err != nil {
+		return fmt.Errorf("failed to upsert unclaimed balance: %w", err)
+	}
+
+	return nil
+}
+
+// ListDueUnclaimedBalances retrieves positive balances funded before the given time
+func (r *Repository) ListDueUnclaimedBalances(ctx context.Context, fundedBefore time.Time) ([]*autorefund.Balance, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListDueUnclaimedBalances")
+	defer span.End()
+
+	dbBalances, err := r.queries.ListDueUnclaimedBalances(ctx, r.db, sql.NullTime{Time: fundedBefore, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unclaimed balances: %w", err)
+	}
+
+	balances := make([]*autorefund.Balance, len(dbBalances))
+	for i, dbBalance := range dbBalances {
+		balances[i] = convertUnclaimedBalance(dbBalance)
+	}
+
+	return balances, nil
+}
+
+// CreateAutoRefund stores an automatic refund attempt
+func (r *Repository) CreateAutoRefund(ctx context.Context, refund *autorefund.Refund) (*autorefund.Refund, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.CreateAutoRefund")
+	defer span.End()
+
+	params := sqlc.CreateAutoRefundParams{
+		ID:            refund.ID,
+		CustomerID:    refund.CustomerID,
+		Currency:      refund.Currency,
+		Amount:        refund.Amount,
+		RefundID:      refund.RefundID,
+		Status:        refund.Status,
+		FailureReason: refund.FailureReason,
+		FundedAt:      refund.FundedAt,
+	}
+
+	dbRefund, err := r.queries.CreateAutoRefund(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create auto-refund: %w", err)
+	}
+
+	return convertAutoRefund(dbRefund), nil
+}
+
+// ListAutoRefunds retrieves recent automatic refunds, optionally for one customer
+func (r *Repository) ListAutoRefunds(ctx context.Context, customerID string, limit int) ([]*autorefund.Refund, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListAutoRefunds")
+	defer span.End()
+
+	dbRefunds, err := r.queries.ListAutoRefunds(ctx, r.db, sqlc.ListAutoRefundsParams{CustomerID: customerID, Limit: int32(limit)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list auto-refunds: %w", err)
+	}
+
+	refunds := make([]*autorefund.Refund, len(dbRefunds))
+	for i, dbRefund := range dbRefunds {
+		refunds[i] = convertAutoRefund(dbRefund)
+	}
+
+	return refunds, nil
+}
+
+// GetAutoRefundExclusion retrieves a customer's auto-refund exclusion
+func (r *Repository) GetAutoRefundExclusion(ctx context.Context, customerID string) (*autorefund.Exclusion, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetAutoRefundExclusion")
+	defer span.End()
+
+	dbExclusion, err := r.queries.GetAutoRefundExclusion(ctx, r.db, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get auto-refund exclusion: %w", err)
+	}
+
+	return convertAutoRefundExclusion(dbExclusion), nil
+}
+
+// ListAutoRefundExclusions retrieves every auto-refund exclusion
+func (r *Repository) ListAutoRefundExclusions(ctx context.Context) ([]*autorefund.Exclusion, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListAutoRefundExclusions")
+	defer span.End()
+
+	dbExclusions, err := r.queries.ListAutoRefundExclusions(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list auto-refund exclusions: %w", err)
+	}
+
+	exclusions := make([]*autorefund.Exclusion, len(dbExclusions))
+	for i, dbExclusion := range dbExclusions {
+		exclusions[i] = convertAutoRefundExclusion(dbExclusion)
+	}
+
+	return exclusions, nil
+}
+
+// UpsertAutoRefundExclusion creates or replaces a customer's auto-refund exclusion
+func (r *Repository) UpsertAutoRefundExclusion(ctx context.Context, exclusion *autorefund.Exclusion) (*autorefund.Exclusion, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.UpsertAutoRefundExclusion")
+	defer span.End()
+
+	params := sqlc.UpsertAutoRefundExclusionParams{
+		CustomerID: exclusion.CustomerID,
+		Reason:     exclusion.Reason,
+	}
+
+	dbExclusion, err := r.queries.UpsertAutoRefundExclusion(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert auto-refund exclusion: %w", err)
+	}
+
+	return convertAutoRefundExclusion(dbExclusion), nil
+}
+
+// DeleteAutoRefundExclusion removes a customer's exclusion, reporting whether one existed
+func (r *Repository) DeleteAutoRefundExclusion(ctx context.Context, customerID string) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.DeleteAutoRefundExclusion")
+	defer span.End()
+
+	rows, err := r.queries.DeleteAutoRefundExclusion(ctx, r.db, customerID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete auto-refund exclusion: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// convertUnclaimedBalance converts a database unclaimed balance to a balance
+func convertUnclaimedBalance(dbBalance sqlc.UnclaimedBalance) *autorefund.Balance {
+	balance := &autorefund.Balance{
+		CustomerID: dbBalance.CustomerID,
+		Currency:   dbBalance.Currency,
+		Amount:     dbBalance.Amount,
+	}
+	if dbBalance.FundedAt.Valid {
+		fundedAt := dbBalance.FundedAt.Time
+		balance.FundedAt = &fundedAt
+	}
+	return balance
+}
+
+// convertAutoRefund converts a database auto-refund to an auto-refund
+func convertAutoRefund(dbRefund sqlc.AutoRefund) *autorefund.Refund {
+	return &autorefund.Refund{
+		ID:            dbRefund.ID,
+		CustomerID:    dbRefund.CustomerID,
+		Currency:      dbRefund.Currency,
+		Amount:        dbRefund.Amount,
+		RefundID:      dbRefund.RefundID,
+		Status:        dbRefund.Status,
+		FailureReason: dbRefund.FailureReason,
+		FundedAt:      dbRefund.FundedAt,
+		CreatedAt:     dbRefund.CreatedAt.Time,
+	}
+}
+
+// convertAutoRefundExclusion converts a database exclusion to an exclusion
+func convertAutoRefundExclusion(dbExclusion sqlc.AutoRefundExclusion) *autorefund.Exclusion {
+	return &autorefund.Exclusion{
+		CustomerID: dbExclusion.CustomerID,
+		Reason:     dbExclusion.Reason,
+		CreatedAt:  dbExclusion.CreatedAt.Time,
+	}
+}
@@ -0,0 +1,117 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/blocklist"
+)
+
+// CreateBlocklistEntry blocks a value, returning the existing entry if it is already blocked
+func (r *Repository) CreateBlocklistEntry(ctx context.Context, entry *blocklist.Entry) (*blocklist.Entry, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.CreateBlocklistEntry")
+	defer span.End()
+
+	params := sqlc.CreateBlocklistEntryParams{
+		ID:             entry.ID,
+		EntryType:      entry.Type,
+		Value:          entry.Value,
+		Reason:         entry.Reason,
+		Source:         entry.Source,
+		CreatedBy:      entry.CreatedBy,
+		ProviderItemID: entry.ProviderItemID,
+	}
+
+	dbEntry, err := r.queries.CreateBlocklistEntry(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create blocklist entry: %w", err)
+	}
+
+	return convertBlocklistEntry(dbEntry), nil
+}
+
+// GetBlocklistEntry retrieves a blocklist entry
+func (r *Repository) GetBlocklistEntry(ctx context.Context, id string) (*blocklist.Entry, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetBlocklistEntry")
+	defer span.End()
+
+	dbEntry, err := r.queries.GetBlocklistEntry(ctx, r.db, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blocklist entry: %w", err)
+	}
+
+	return convertBlocklistEntry(dbEntry), nil
+}
+
+// GetBlocklistEntryByValue retrieves the entry blocking a value
+func (r *Repository) GetBlocklistEntryByValue(ctx context.Context, entryType, value string) (*blocklist.Entry, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetBlocklistEntryByValue")
+	defer span.End()
+
+	params := sqlc.GetBlocklistEntryByValueParams{EntryType: entryType, Value: value}
+	dbEntry, err := r.queries.GetBlocklistEntryByValue(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blocklist entry: %w", err)
+	}
+
+	return convertBlocklistEntry(dbEntry), nil
+}
+
+// ListBlocklistEntries retrieves blocklist entries, optionally of one type
+func (r *Repository) ListBlocklistEntries(ctx context.Context, entryType string) ([]*blocklist.Entry, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListBlocklistEntries")
+	defer span.End()
+
+	dbEntries, err := r.queries.ListBlocklistEntries(ctx, r.db, entryType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blocklist entries: %w", err)
+	}
+
+	entries := make([]*blocklist.Entry, len(dbEntries))
+	for i, dbEntry := range dbEntries {
+		entries[i] = convertBlocklistEntry(dbEntry)
+	}
+
+	return entries, nil
+}
+
+// SetBlocklistEntryProviderItem links a blocklist entry to its provider value list item
+func (r *Repository) SetBlocklistEntryProviderItem(ctx context.Context, id, providerItemID string) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.SetBlocklistEntryProviderItem")
+	defer span.End()
+
+	params := sqlc.SetBlocklistEntryProviderItemParams{ID: id, ProviderItemID: providerItemID}
+	if err := r.queries.SetBlocklistEntryProviderItem(ctx, r.db, params); err != nil {
+		return fmt.Errorf("failed to link blocklist entry: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteBlocklistEntry removes a blocklist entry, reporting whether it existed
+func (r *Repository) DeleteBlocklistEntry(ctx context.Context, id string) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.DeleteBlocklistEntry")
+	defer span.End()
+
+	rows, err := r.queries.DeleteBlocklistEntry(ctx, r.db, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete blocklist entry: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// convertBlocklistEntry converts a database blocklist entry to a service entry
+func convertBlocklistEntry(dbEntry sqlc.BlocklistEntry) *blocklist.Entry {
+	return &blocklist.Entry{
+		ID:             dbEntry.ID,
+		Type:           dbEntry.EntryType,
+		Value:          dbEntry.Value,
+		Reason:         dbEntry.Reason,
+		Source:         dbEntry.Source,
+		CreatedBy:      dbEntry.CreatedBy,
+		ProviderItemID: dbEntry.ProviderItemID,
+		CreatedAt:      dbEntry.CreatedAt.Time,
+	}
+}
@@ -0,0 +1,134 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/budgets"
+)
+
+// GetTenantBudget retrieves a tenant's monthly budget
+func (r *Repository) GetTenantBudget(ctx context.Context, tenantID string) (*budgets.Budget, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetTenantBudget")
+	defer span.End()
+
+	dbBudget, err := r.queries.GetTenantBudget(ctx, r.db, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant budget: %w", err)
+	}
+
+	return convertTenantBudget(dbBudget), nil
+}
+
+// UpsertTenantBudget creates or replaces a tenant's monthly budget
+func (r *Repository) UpsertTenantBudget(ctx context.Context, budget *budgets.Budget) (*budgets.Budget, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.UpsertTenantBudget")
+	defer span.End()
+
+	thresholds, err := json.Marshal(budget.Thresholds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal budget thresholds: %w", err)
+	}
+
+	params := sqlc.UpsertTenantBudgetParams{
+		TenantID:     budget.TenantID,
+		MonthlyLimit: budget.MonthlyLimit,
+		Thresholds:   thresholds,
+		HardStop:     budget.HardStop,
+		UpdatedBy:    budget.UpdatedBy,
+	}
+
+	dbBudget, err := r.queries.UpsertTenantBudget(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert tenant budget: %w", err)
+	}
+
+	return convertTenantBudget(dbBudget), nil
+}
+
+// DeleteTenantBudget removes a tenant's budget, reporting whether one existed
+func (r *Repository) DeleteTenantBudget(ctx context.Context, tenantID string) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.DeleteTenantBudget")
+	defer span.End()
+
+	rows, err := r.queries.DeleteTenantBudget(ctx, r.db, tenantID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete tenant budget: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// GetTenantSpend retrieves a tenant's processed volume for a month
+func (r *Repository) GetTenantSpend(ctx context.Context, tenantID, period string) (*budgets.Spend, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetTenantSpend")
+	defer span.End()
+
+	params := sqlc.GetTenantSpendParams{TenantID: tenantID, Period: period}
+	dbSpend, err := r.queries.GetTenantSpend(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant spend: %w", err)
+	}
+
+	return convertTenantSpend(dbSpend), nil
+}
+
+// AddTenantSpend adds a charge to a tenant's monthly volume, returning the new total
+func (r *Repository) AddTenantSpend(ctx context.Context, tenantID, period string, amount int64) (*budgets.Spend, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.AddTenantSpend")
+	defer span.End()
+
+	params := sqlc.AddTenantSpendParams{TenantID: tenantID, Period: period, Amount: amount}
+	dbSpend, err := r.queries.AddTenantSpend(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add tenant spend: %w", err)
+	}
+
+	return convertTenantSpend(dbSpend), nil
+}
+
+// RecordBudgetAlert marks a threshold as alerted for a month, reporting
+// whether it had not been alerted before
+func (r *Repository) RecordBudgetAlert(ctx context.Context, tenantID, period string, threshold int) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.RecordBudgetAlert")
+	defer span.End()
+
+	params := sqlc.RecordBudgetAlertParams{
+		TenantID:  tenantID,
+		Period:    period,
+		Threshold: int32(threshold),
+	}
+
+	rows, err := r.queries.RecordBudgetAlert(ctx, r.db, params)
+	if err != nil {
+		return false, fmt.Errorf("failed to record budget alert: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// convertTenantBudget converts a database tenant budget to a service budget
+func convertTenantBudget(dbBudget sqlc.TenantBudget) *budgets.Budget {
+	budget := &budgets.Budget{
+		TenantID:     dbBudget.TenantID,
+		MonthlyLimit: dbBudget.MonthlyLimit,
+		HardStop:     dbBudget.HardStop,
+		UpdatedBy:    dbBudget.UpdatedBy,
+		UpdatedAt:    dbBudget.UpdatedAt.Time,
+	}
+	_ = json.Unmarshal(dbBudget.Thresholds, &budget.Thresholds)
+
+	return budget
+}
+
+// convertTenantSpend converts database tenant spend to service spend
+func convertTenantSpend(dbSpend sqlc.TenantSpend) *budgets.Spend {
+	return &budgets.Spend{
+		TenantID:    dbSpend.TenantID,
+		Period:      dbSpend.Period,
+		Amount:      dbSpend.Amount,
+		ChargeCount: dbSpend.ChargeCount,
+	}
+}
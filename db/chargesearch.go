@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/chargesearch"
+	"apis/payments/services/money"
+	"apis/payments/services/stripe"
+)
+
+// SearchCharges lists a tenant's mirrored charges matching a filter, newest
+// first
+func (r *Repository) SearchCharges(ctx context.Context, filter chargesearch.Filter) ([]*stripe.Charge, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.SearchCharges")
+	defer span.End()
+
+	metadata, err := json.Marshal(filter.Metadata)
+	if err != nil || len(filter.Metadata) == 0 {
+		metadata = []byte("{}")
+	}
+
+	params := sqlc.SearchTenantChargesParams{
+		TenantID:      filter.TenantID,
+		Status:        filter.Status,
+		Currency:      filter.Currency,
+		CustomerID:    filter.CustomerID,
+		CardBrand:     filter.CardBrand,
+		CardLast4:     filter.CardLast4,
+		Metadata:      metadata,
+		StartingAfter: filter.StartingAfter,
+		Limit:         int32(filter.Limit),
+	}
+	if filter.MinAmount != nil {
+		params.MinAmount = sql.NullInt64{Int64: *filter.MinAmount, Valid: true}
+	}
+	if filter.MaxAmount != nil {
+		params.MaxAmount = sql.NullInt64{Int64: *filter.MaxAmount, Valid: true}
+	}
+	if filter.CreatedAfter != nil {
+		params.CreatedAfter = sql.NullTime{Time: *filter.CreatedAfter, Valid: true}
+	}
+	if filter.CreatedBefore != nil {
+		params.CreatedBefore = sql.NullTime{Time: *filter.CreatedBefore, Valid: true}
+	}
+
+	dbCharges, err := r.queries.SearchTenantCharges(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search charges: %w", err)
+	}
+
+	charges := make([]*stripe.Charge, len(dbCharges))
+	for i, dbCharge := range dbCharges {
+		charges[i] = &stripe.Charge{
+			ID:              dbCharge.ID,
+			Amount:          dbCharge.Amount,
+			AmountDecimal:   money.FormatDecimal(dbCharge.Amount, dbCharge.Currency),
+			AmountDisplay:   money.Describe(dbCharge.Amount, dbCharge.Currency),
+			AmountRefunded:  dbCharge.AmountRefunded,
+			Currency:        dbCharge.Currency,
+			Status:          dbCharge.Status,
+			Captured:        dbCharge.Captured,
+			Refunded:        dbCharge.Refunded,
+			Disputed:        dbCharge.Disputed,
+			CustomerID:      dbCharge.CustomerID,
+			PaymentMethodID: dbCharge.PaymentMethodID.String,
+			InvoiceID:       dbCharge.InvoiceID,
+			Description:     dbCharge.Description.String,
+			Metadata:        decodeMetadata(dbCharge.Metadata),
+			Created:         dbCharge.CreatedAt.Time.Unix(),
+		}
+	}
+
+	return charges, nil
+}
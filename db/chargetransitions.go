@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/chargestate"
+)
+
+// AppendChargeTransition records a charge state transition. It returns
+// sql.ErrNoRows if a transition with the same sequence was recorded first.
+func (r *Repository) AppendChargeTransition(ctx context.Context, transition *chargestate.Transition) (*chargestate.Transition, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.AppendChargeTransition")
+	defer span.End()
+
+	params := sqlc.AppendChargeTransitionParams{
+		ID:        transition.ID,
+		ChargeID:  transition.ChargeID,
+		Sequence:  int32(transition.Sequence),
+		FromState: transition.From,
+		ToState:   transition.To,
+		Source:    transition.Source,
+		EventID:   transition.EventID,
+	}
+
+	dbTransition, err := r.queries.AppendChargeTransition(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to append charge transition: %w", err)
+	}
+
+	return convertChargeTransition(dbTransition), nil
+}
+
+// GetLatestChargeTransition retrieves a charge's latest transition
+func (r *Repository) GetLatestChargeTransition(ctx context.Context, chargeID string) (*chargestate.Transition, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetLatestChargeTransition")
+	defer span.End()
+
+	dbTransition, err := r.queries.GetLatestChargeTransition(ctx, r.db, chargeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest charge transition: %w", err)
+	}
+
+	return convertChargeTransition(dbTransition), nil
+}
+
+// ListChargeTransitions retrieves a charge's transitions, oldest first
+func (r *Repository) ListChargeTransitions(ctx context.Context, chargeID string) ([]*chargestate.Transition, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListChargeTransitions")
+	defer span.End()
+
+	dbTransitions, err := r.queries.ListChargeTransitions(ctx, r.db, chargeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list charge transitions: %w", err)
+	}
+
+	transitions := make([]*chargestate.Transition, len(dbTransitions))
+	for i, dbTransition := range dbTransitions {
+		transitions[i] = convertChargeTransition(dbTransition)
+	}
+
+	return transitions, nil
+}
+
+// convertChargeTransition converts a database charge transition to a service transition
+func convertChargeTransition(dbTransition sqlc.ChargeTransition) *chargestate.Transition {
+	return &chargestate.Transition{
+		ID:        dbTransition.ID,
+		ChargeID:  dbTransition.ChargeID,
+		Sequence:  int(dbTransition.Sequence),
+		From:      dbTransition.FromState,
+		To:        dbTransition.ToState,
+		Source:    dbTransition.Source,
+		EventID:   dbTransition.EventID,
+		CreatedAt: dbTransition.CreatedAt.Time,
+	}
+}
@@ -7,76 +7,77 @@ import (
 	"time"
 
 	"apis/payments/services/stripe"
+	"apis/payments/services/tenancy"
 
 	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/google/uuid"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/trace"
 )
 
-// AnalyticsService provides ClickHouse analytics operations
+// Tables analytics events are written to, created by the embedded migrations
+var (
+	paymentEventsTable = &Table{Name: "payment_events", Columns: []string{
+		"event_id", "event_type", "tenant_id", "charge_id", "customer_id", "payment_method_id", "amount", "currency",
+		"status", "description", "failure_code", "metadata", "created_at", "timestamp",
+	}}
+	customerEventsTable = &Table{Name: "customer_events", Columns: []string{
+		"event_id", "event_type", "customer_id", "email", "name", "phone",
+		"description", "metadata", "created_at", "timestamp",
+	}}
+	paymentMethodEventsTable = &Table{Name: "payment_method_events", Columns: []string{
+		"event_id", "event_type", "payment_method_id", "customer_id", "type",
+		"card_last4", "card_brand", "card_exp_month", "card_exp_year",
+		"metadata", "created_at", "timestamp",
+	}}
+	refundEventsTable = &Table{Name: "refund_events", Columns: []string{
+		"event_id", "event_type", "refund_id", "charge_id", "amount", "currency",
+		"status", "reason", "metadata", "created_at", "timestamp",
+	}}
+	disputeEventsTable = &Table{Name: "dispute_events", Columns: []string{
+		"event_id", "event_type", "dispute_id", "charge_id", "amount", "currency", "status",
+		"reason", "network_reason_code", "metadata", "created_at", "timestamp",
+	}}
+	subscriptionEventsTable = &Table{Name: "subscription_events", Columns: []string{
+		"event_id", "event_type", "tenant_id", "subscription_id", "customer_id", "price_id", "quantity",
+		"currency", "mrr", "status",
+		"cancel_at_period_end", "current_period_start", "current_period_end",
+		"metadata", "created_at", "timestamp",
+	}}
+	payoutEventsTable = &Table{Name: "payout_events", Columns: []string{
+		"event_id", "event_type", "payout_id", "amount", "currency", "status",
+		"arrival_date", "created_at", "timestamp",
+	}}
+)
+
+// AnalyticsService provides ClickHouse analytics operations. Events are
+// queued on a writer that inserts them in batches, so logging never waits
+// on ClickHouse.
 type AnalyticsService struct {
-	conn   clickhouse.Conn
-	tracer trace.Tracer
+	conn        clickhouse.Conn
+	writer      *Writer
+	tracer      trace.Tracer
+	skipRelayed bool
 }
 
-// NewAnalyticsService creates a new analytics service
-func NewAnalyticsService(conn clickhouse.Conn) *AnalyticsService {
+// NewAnalyticsService creates a new analytics service logging events
+// through writer
+func NewAnalyticsService(conn clickhouse.Conn, writer *Writer) *AnalyticsService {
 	return &AnalyticsService{
 		conn:   conn,
+		writer: writer,
 		tracer: otel.Tracer("payments.analytics"),
 	}
 }
 
-// LogCharge logs a charge event to ClickHouse for analytics
-func (a *AnalyticsService) LogCharge(ctx context.Context, charge *stripe.Charge) error {
-	ctx, span := a.tracer.Start(ctx, "AnalyticsService.LogCharge")
+// LogChargeEvent logs a charge event to ClickHouse
+func (a *AnalyticsService) LogChargeEvent(ctx context.Context, eventType string, charge *stripe.Charge) error {
+	_, span := a.tracer.Start(ctx, "AnalyticsService.LogChargeEvent")
 	defer span.End()
 
-	// Prepare the charge data for ClickHouse
-	chargeData := map[string]interface{}{
-		"id":                charge.ID,
-		"amount":            charge.Amount,
-		"currency":          charge.Currency,
-		"status":            charge.Status,
-		"customer_id":       charge.CustomerID,
-		"payment_method_id": charge.PaymentMethodID,
-		"description":       charge.Description,
-		"created_at":        time.Unix(charge.Created, 0),
-		"event_type":        "charge_created",
-		"timestamp":         time.Now(),
-	}
-
-	// Convert metadata to JSON
-	if charge.Metadata != nil {
-		if metadataJSON, err := json.Marshal(charge.Metadata); err == nil {
-			chargeData["metadata"] = string(metadataJSON)
-		}
-	}
-
-	// Insert into ClickHouse
-	query := `
-		INSERT INTO payment_events (
-			event_id, event_type, customer_id, amount, currency, status, 
-			metadata, created_at, timestamp
-		) VALUES (
-			?, ?, ?, ?, ?, ?, ?, ?, ?
-		)
-	`
-
-	err := a.conn.Exec(ctx, query,
-		charge.ID,
-		"charge_created",
-		charge.CustomerID,
-		charge.Amount,
-		charge.Currency,
-		charge.Status,
-		chargeData["metadata"],
-		chargeData["created_at"],
-		chargeData["timestamp"],
-	)
-
+	err := a.writer.Write(paymentEventsTable, chargeEventRow(uuid.New().String(), eventType, charge, time.Now())...)
 	if err != nil {
-		return fmt.Errorf("failed to log charge to ClickHouse: %w", err)
+		return fmt.Errorf("failed to log charge event: %w", err)
 	}
 
 	return nil
@@ -84,54 +85,23 @@ func (a *AnalyticsService) LogCharge(ctx context.Context, charge *stripe.Charge)
 
 // LogCustomerEvent logs a customer event to ClickHouse
 func (a *AnalyticsService) LogCustomerEvent(ctx context.Context, eventType string, customer *stripe.Customer) error {
-	ctx, span := a.tracer.Start(ctx, "AnalyticsService.LogCustomerEvent")
+	_, span := a.tracer.Start(ctx, "AnalyticsService.LogCustomerEvent")
 	defer span.End()
 
-	// Prepare the customer data for ClickHouse
-	customerData := map[string]interface{}{
-		"id":          customer.ID,
-		"email":       customer.Email,
-		"name":        customer.Name,
-		"phone":       customer.Phone,
-		"description": customer.Description,
-		"created_at":  time.Unix(customer.Created, 0),
-		"event_type":  eventType,
-		"timestamp":   time.Now(),
-	}
-
-	// Convert metadata to JSON
-	var metadataJSON string
-	if customer.Metadata != nil {
-		if jsonData, err := json.Marshal(customer.Metadata); err == nil {
-			metadataJSON = string(jsonData)
-		}
-	}
-
-	// Insert into ClickHouse
-	query := `
-		INSERT INTO customer_events (
-			event_id, event_type, customer_id, email, name, phone, 
-			description, metadata, created_at, timestamp
-		) VALUES (
-			?, ?, ?, ?, ?, ?, ?, ?, ?, ?
-		)
-	`
-
-	err := a.conn.Exec(ctx, query,
-		customer.ID,
+	err := a.writer.Write(customerEventsTable,
+		uuid.New().String(),
 		eventType,
 		customer.ID,
 		customer.Email,
 		customer.Name,
 		customer.Phone,
 		customer.Description,
-		metadataJSON,
-		customerData["created_at"],
-		customerData["timestamp"],
+		encodeMetadata(customer.Metadata),
+		time.Unix(customer.Created, 0),
+		time.Now(),
 	)
-
 	if err != nil {
-		return fmt.Errorf("failed to log customer event to ClickHouse: %w", err)
+		return fmt.Errorf("failed to log customer event: %w", err)
 	}
 
 	return nil
@@ -139,73 +109,209 @@ func (a *AnalyticsService) LogCustomerEvent(ctx context.Context, eventType strin
 
 // LogPaymentMethodEvent logs a payment method event to ClickHouse
 func (a *AnalyticsService) LogPaymentMethodEvent(ctx context.Context, eventType string, paymentMethod *stripe.PaymentMethod) error {
-	ctx, span := a.tracer.Start(ctx, "AnalyticsService.LogPaymentMethodEvent")
+	_, span := a.tracer.Start(ctx, "AnalyticsService.LogPaymentMethodEvent")
 	defer span.End()
 
-	// Prepare the payment method data for ClickHouse
-	paymentMethodData := map[string]interface{}{
-		"id":          paymentMethod.ID,
-		"type":        paymentMethod.Type,
-		"customer_id": paymentMethod.Customer,
-		"created_at":  time.Unix(paymentMethod.Created, 0),
-		"event_type":  eventType,
-		"timestamp":   time.Now(),
+	// Card details are left empty for other payment method types
+	var (
+		last4, brand string
+		expMonth     uint8
+		expYear      uint16
+	)
+	if paymentMethod.Card != nil {
+		last4 = paymentMethod.Card.Last4
+		brand = paymentMethod.Card.Brand
+		expMonth = uint8(paymentMethod.Card.ExpMonth)
+		expYear = uint16(paymentMethod.Card.ExpYear)
 	}
 
-	// Add card details if available
-	if paymentMethod.Card != nil {
-		paymentMethodData["card_last4"] = paymentMethod.Card.Last4
-		paymentMethodData["card_brand"] = paymentMethod.Card.Brand
-		paymentMethodData["card_exp_month"] = paymentMethod.Card.ExpMonth
-		paymentMethodData["card_exp_year"] = paymentMethod.Card.ExpYear
+	err := a.writer.Write(paymentMethodEventsTable,
+		uuid.New().String(),
+		eventType,
+		paymentMethod.ID,
+		paymentMethod.Customer,
+		paymentMethod.Type,
+		last4,
+		brand,
+		expMonth,
+		expYear,
+		encodeMetadata(paymentMethod.Metadata),
+		time.Unix(paymentMethod.Created, 0),
+		time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to log payment method event: %w", err)
 	}
 
-	// Convert metadata to JSON
-	var metadataJSON string
-	if paymentMethod.Metadata != nil {
-		if jsonData, err := json.Marshal(paymentMethod.Metadata); err == nil {
-			metadataJSON = string(jsonData)
-		}
+	return nil
+}
+
+// LogRefundEvent logs a refund event to ClickHouse
+func (a *AnalyticsService) LogRefundEvent(ctx context.Context, eventType string, refund *stripe.Refund) error {
+	_, span := a.tracer.Start(ctx, "AnalyticsService.LogRefundEvent")
+	defer span.End()
+
+	err := a.writer.Write(refundEventsTable, refundEventRow(uuid.New().String(), eventType, refund, time.Now())...)
+	if err != nil {
+		return fmt.Errorf("failed to log refund event: %w", err)
 	}
 
-	// Insert into ClickHouse
-	query := `
-		INSERT INTO payment_method_events (
-			event_id, event_type, payment_method_id, customer_id, type,
-			card_last4, card_brand, card_exp_month, card_exp_year,
-			metadata, created_at, timestamp
-		) VALUES (
-			?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
-		)
-	`
+	return nil
+}
 
-	err := a.conn.Exec(ctx, query,
-		paymentMethod.ID,
+// LogDisputeEvent logs a dispute event to ClickHouse
+func (a *AnalyticsService) LogDisputeEvent(ctx context.Context, eventType string, dispute *stripe.Dispute) error {
+	_, span := a.tracer.Start(ctx, "AnalyticsService.LogDisputeEvent")
+	defer span.End()
+
+	err := a.writer.Write(disputeEventsTable, disputeEventRow(uuid.New().String(), eventType, dispute, time.Now())...)
+	if err != nil {
+		return fmt.Errorf("failed to log dispute event: %w", err)
+	}
+
+	return nil
+}
+
+// LogSubscriptionEvent logs a subscription event to ClickHouse along with
+// its monthly recurring revenue, in the currency of its prices
+func (a *AnalyticsService) LogSubscriptionEvent(ctx context.Context, eventType string, subscription *stripe.Subscription, currency string, mrr int64) error {
+	_, span := a.tracer.Start(ctx, "AnalyticsService.LogSubscriptionEvent")
+	defer span.End()
+
+	var cancelAtPeriodEnd uint8
+	if subscription.CancelAtPeriodEnd {
+		cancelAtPeriodEnd = 1
+	}
+
+	err := a.writer.Write(subscriptionEventsTable,
+		uuid.New().String(),
 		eventType,
-		paymentMethod.ID,
-		paymentMethod.Customer,
-		paymentMethod.Type,
-		paymentMethodData["card_last4"],
-		paymentMethodData["card_brand"],
-		paymentMethodData["card_exp_month"],
-		paymentMethodData["card_exp_year"],
-		metadataJSON,
-		paymentMethodData["created_at"],
-		paymentMethodData["timestamp"],
+		tenantOf(subscription.Metadata),
+		subscription.ID,
+		subscription.CustomerID,
+		subscription.PriceID,
+		subscription.Quantity,
+		currency,
+		mrr,
+		subscription.Status,
+		cancelAtPeriodEnd,
+		time.Unix(subscription.CurrentPeriodStart, 0),
+		time.Unix(subscription.CurrentPeriodEnd, 0),
+		encodeMetadata(subscription.Metadata),
+		time.Unix(subscription.Created, 0),
+		time.Now(),
 	)
+	if err != nil {
+		return fmt.Errorf("failed to log subscription event: %w", err)
+	}
+
+	return nil
+}
 
+// LogPayoutEvent logs a payout event to ClickHouse
+func (a *AnalyticsService) LogPayoutEvent(ctx context.Context, eventType string, payout *stripe.Payout) error {
+	_, span := a.tracer.Start(ctx, "AnalyticsService.LogPayoutEvent")
+	defer span.End()
+
+	err := a.writer.Write(payoutEventsTable,
+		uuid.New().String(),
+		eventType,
+		payout.ID,
+		payout.Amount,
+		payout.Currency,
+		payout.Status,
+		payout.ArrivalDate,
+		payout.Created,
+		time.Now(),
+	)
 	if err != nil {
-		return fmt.Errorf("failed to log payment method event to ClickHouse: %w", err)
+		return fmt.Errorf("failed to log payout event: %w", err)
 	}
 
 	return nil
 }
 
+// chargeEventRow builds the payment_events row for an event about a charge
+func chargeEventRow(eventID, eventType string, charge *stripe.Charge, timestamp time.Time) []any {
+	return []any{
+		eventID,
+		eventType,
+		tenantOf(charge.Metadata),
+		charge.ID,
+		charge.CustomerID,
+		charge.PaymentMethodID,
+		charge.Amount,
+		charge.Currency,
+		charge.Status,
+		charge.Description,
+		charge.FailureCode,
+		encodeMetadata(charge.Metadata),
+		time.Unix(charge.Created, 0),
+		timestamp,
+	}
+}
+
+// refundEventRow builds the refund_events row for an event about a refund
+func refundEventRow(eventID, eventType string, refund *stripe.Refund, timestamp time.Time) []any {
+	return []any{
+		eventID,
+		eventType,
+		refund.ID,
+		refund.ChargeID,
+		refund.Amount,
+		refund.Currency,
+		refund.Status,
+		refund.Reason,
+		encodeMetadata(refund.Metadata),
+		refund.CreatedAt,
+		timestamp,
+	}
+}
+
+// disputeEventRow builds the dispute_events row for an event about a dispute
+func disputeEventRow(eventID, eventType string, dispute *stripe.Dispute, timestamp time.Time) []any {
+	return []any{
+		eventID,
+		eventType,
+		dispute.ID,
+		dispute.ChargeID,
+		dispute.Amount,
+		dispute.Currency,
+		dispute.Status,
+		dispute.Reason,
+		dispute.NetworkReasonCode,
+		encodeMetadata(dispute.Metadata),
+		time.Unix(dispute.Created, 0),
+		timestamp,
+	}
+}
+
+// tenantOf resolves the tenant named by an object's metadata
+func tenantOf(metadata map[string]string) string {
+	if tenantID := metadata["tenant_id"]; tenantID != "" {
+		return tenantID
+	}
+	return tenancy.DefaultTenantID
+}
+
+// encodeMetadata stores metadata as a JSON string, empty without metadata
+func encodeMetadata(metadata map[string]string) string {
+	if len(metadata) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
 // GetChargeMetrics retrieves charge metrics from ClickHouse
 func (a *AnalyticsService) GetChargeMetrics(ctx context.Context, days int) (map[string]interface{}, error) {
 	ctx, span := a.tracer.Start(ctx, "AnalyticsService.GetChargeMetrics")
 	defer span.End()
 
+	// Every charge ends up either succeeded or failed
 	query := `
 		SELECT 
 			count() as total_charges,
@@ -214,7 +320,7 @@ func (a *AnalyticsService) GetChargeMetrics(ctx context.Context, days int) (map[
 			countIf(status = 'succeeded') as successful_charges,
 			sumIf(amount, status = 'succeeded') as successful_amount
 		FROM payment_events 
-		WHERE event_type = 'charge_created' 
+		WHERE event_type IN ('charge_succeeded', 'charge_failed')
 		AND timestamp >= now() - INTERVAL ? DAY
 	`
 
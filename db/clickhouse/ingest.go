@@ -0,0 +1,177 @@
+package clickhouse
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"apis/payments/services/events"
+	"apis/payments/services/kafka"
+	"apis/payments/services/relay"
+
+	stripego "github.com/stripe/stripe-go/v76"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Selector runs queries scanning their rows into dest
+type Selector interface {
+	Select(ctx context.Context, dest any, query string, args ...any) error
+}
+
+// relayedEvent is where an event type published by the relay is stored
+type relayedEvent struct {
+	table     *Table
+	eventType string
+	row       func(eventID, eventType string, data json.RawMessage, timestamp time.Time) ([]any, error)
+}
+
+// relayedEvents maps the types charge, refund and dispute events are relayed
+// as to the rows webhook logging stores for them, e.g.
+// payments.charge.succeeded to a charge_succeeded row of payment_events
+var relayedEvents = func() map[string]relayedEvent {
+	relayed := map[string]relayedEvent{}
+	relayedRows(relayed, paymentEventsTable, chargeEvents, chargeEventRow)
+	relayedRows(relayed, refundEventsTable, refundEvents, refundEventRow)
+	relayedRows(relayed, disputeEventsTable, disputeEvents, disputeEventRow)
+	return relayed
+}()
+
+// relayedRows maps the relayed types of eventTypes to rows of table built
+// from their normalized object
+func relayedRows[T any](relayed map[string]relayedEvent, table *Table, eventTypes []stripego.EventType, row func(eventID, eventType string, object *T, timestamp time.Time) []any) {
+	for _, eventType := range eventTypes {
+		relayed[relay.EventType(eventType)] = relayedEvent{
+			table:     table,
+			eventType: eventTypeName(eventType),
+			row: func(eventID, eventType string, data json.RawMessage, timestamp time.Time) ([]any, error) {
+				var object T
+				if err := json.Unmarshal(data, &object); err != nil {
+					return nil, err
+				}
+				return row(eventID, eventType, &object, timestamp), nil
+			},
+		}
+	}
+}
+
+// ingestedRow is a row of an event being ingested
+type ingestedRow struct {
+	eventID   string
+	timestamp time.Time
+	values    []any
+}
+
+// Ingester writes the charge, refund and dispute events relayed to the
+// events topic to the analytics tables, in place of webhook logging. Each
+// event is stored once: rows are keyed by the event's ID, which a relayed
+// event shares with every redelivery of its provider event, and events
+// already stored are skipped.
+type Ingester struct {
+	conn   Selector
+	insert InsertFunc
+	tracer trace.Tracer
+}
+
+// NewIngester creates a new ingester looking up stored events on conn and
+// writing new ones with insert
+func NewIngester(conn Selector, insert InsertFunc) *Ingester {
+	return &Ingester{
+		conn:   conn,
+		insert: insert,
+		tracer: otel.Tracer("payments.analytics"),
+	}
+}
+
+// HandleBatch stores a batch of consumed events. Other event types, and
+// messages that aren't events, are skipped. A failed insert fails the batch,
+// which is retried; the rows it did insert are skipped the next time.
+func (i *Ingester) HandleBatch(ctx context.Context, messages []*kafka.Message) error {
+	ctx, span := i.tracer.Start(ctx, "Ingester.HandleBatch", trace.WithAttributes(
+		attribute.Int("messaging.batch.message_count", len(messages)),
+	))
+	defer span.End()
+
+	var tables []*Table
+	rows := map[*Table][]*ingestedRow{}
+	seen := map[string]bool{}
+	for _, message := range messages {
+		var event events.Event
+		if err := json.Unmarshal(message.Value, &event); err != nil {
+			log.Printf("Skipping Kafka message %s/%d/%d that isn't an event: %v", message.Topic, message.Partition, message.Offset, err)
+			continue
+		}
+
+		relayed, ok := relayedEvents[event.Type]
+		if !ok || seen[event.ID] {
+			continue
+		}
+		values, err := relayed.row(event.ID, relayed.eventType, event.Data, event.Time)
+		if err != nil {
+			log.Printf("Skipping %s event %s with malformed data: %v", event.Type, event.ID, err)
+			continue
+		}
+		seen[event.ID] = true
+
+		if _, ok := rows[relayed.table]; !ok {
+			tables = append(tables, relayed.table)
+		}
+		rows[relayed.table] = append(rows[relayed.table], &ingestedRow{eventID: event.ID, timestamp: event.Time, values: values})
+	}
+
+	for _, table := range tables {
+		unstored, err := i.unstored(ctx, table, rows[table])
+		if err != nil {
+			return err
+		}
+		if len(unstored) == 0 {
+			continue
+		}
+		if err := i.insert(ctx, table, unstored); err != nil {
+			return fmt.Errorf("failed to insert %d rows into %s: %w", len(unstored), table.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// unstored returns the values of the rows whose events a table doesn't hold
+// yet. A redelivered event keeps its time, so only the partitions of the
+// rows' times are searched.
+func (i *Ingester) unstored(ctx context.Context, table *Table, rows []*ingestedRow) ([][]any, error) {
+	ids := make([]string, len(rows))
+	from, to := rows[0].timestamp, rows[0].timestamp
+	for j, row := range rows {
+		ids[j] = row.eventID
+		if row.timestamp.Before(from) {
+			from = row.timestamp
+		}
+		if row.timestamp.After(to) {
+			to = row.timestamp
+		}
+	}
+
+	var stored []struct {
+		EventID string `ch:"event_id"`
+	}
+	query := fmt.Sprintf("SELECT event_id FROM %s WHERE timestamp >= ? AND timestamp <= ? AND has(?, event_id)", table.Name)
+	if err := i.conn.Select(ctx, &stored, query, from, to, ids); err != nil {
+		return nil, fmt.Errorf("failed to look up stored %s: %w", table.Name, err)
+	}
+
+	storedIDs := make(map[string]bool, len(stored))
+	for _, row := range stored {
+		storedIDs[row.EventID] = true
+	}
+
+	var values [][]any
+	for _, row := range rows {
+		if !storedIDs[row.eventID] {
+			values = append(values, row.values)
+		}
+	}
+	return values, nil
+}
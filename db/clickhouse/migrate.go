@@ -0,0 +1,149 @@
+package clickhouse
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migrator is the part of a ClickHouse connection migrations are run on
+type Migrator interface {
+	Exec(ctx context.Context, query string, args ...any) error
+	Select(ctx context.Context, dest any, query string, args ...any) error
+}
+
+// Migration is a numbered set of statements embedded from migrations/
+type Migration struct {
+	Version    uint32
+	Name       string
+	Statements []string
+}
+
+// Migrations returns the embedded migrations in the order they are applied
+func Migrations() ([]*Migration, error) {
+	entries, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	var migrations []*Migration
+	for _, entry := range entries {
+		number, _, ok := strings.Cut(entry.Name(), "_")
+		version, err := strconv.ParseUint(number, 10, 32)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("migration %s is not numbered", entry.Name())
+		}
+
+		content, err := migrationFiles.ReadFile(path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		migrations = append(migrations, &Migration{
+			Version:    uint32(version),
+			Name:       strings.TrimSuffix(entry.Name(), ".sql"),
+			Statements: splitStatements(string(content)),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("migrations %s and %s share a version", migrations[i-1].Name, migrations[i].Name)
+		}
+	}
+
+	return migrations, nil
+}
+
+// Migrate creates and updates the analytics tables, applying the embedded
+// migrations not yet recorded in schema_migrations in order. Statements
+// are idempotent, so a migration interrupted before it is recorded is
+// applied again in full.
+func Migrate(ctx context.Context, conn Migrator) error {
+	ctx, span := otel.Tracer("payments.analytics").Start(ctx, "Migrate")
+	defer span.End()
+
+	migrations, err := Migrations()
+	if err != nil {
+		return err
+	}
+
+	query := `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version UInt32,
+			name String,
+			applied_at DateTime64(3) DEFAULT now64(3)
+		) ENGINE = ReplacingMergeTree
+		ORDER BY version
+	`
+	if err := conn.Exec(ctx, query); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	var applied []struct {
+		Version uint32 `ch:"version"`
+	}
+	if err := conn.Select(ctx, &applied, `SELECT DISTINCT version FROM schema_migrations`); err != nil {
+		return fmt.Errorf("failed to list applied migrations: %w", err)
+	}
+	done := make(map[uint32]bool, len(applied))
+	for _, migration := range applied {
+		done[migration.Version] = true
+	}
+
+	for _, migration := range migrations {
+		if done[migration.Version] {
+			continue
+		}
+
+		for _, statement := range migration.Statements {
+			if err := conn.Exec(ctx, statement); err != nil {
+				return fmt.Errorf("failed to apply migration %s: %w", migration.Name, err)
+			}
+		}
+
+		query := `INSERT INTO schema_migrations (version, name) VALUES (?, ?)`
+		if err := conn.Exec(ctx, query, migration.Version, migration.Name); err != nil {
+			return fmt.Errorf("failed to record migration %s: %w", migration.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// splitStatements splits a migration on semicolons ending a line, dropping
+// comment lines. Statements must not hold such semicolons in literals.
+func splitStatements(content string) []string {
+	var statements []string
+	var statement strings.Builder
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+
+		statement.WriteString(line)
+		statement.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSuffix(strings.TrimSpace(statement.String()), ";"))
+			statement.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(statement.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+
+	return statements
+}
@@ -0,0 +1,50 @@
+-- Migration to create the analytics event tables
+-- Charges, customers and payment methods are logged as events, one row per
+-- event, partitioned by month.
+
+CREATE TABLE IF NOT EXISTS payment_events (
+    event_id String,
+    event_type LowCardinality(String),
+    charge_id String,
+    customer_id String,
+    amount Int64,
+    currency LowCardinality(String),
+    status LowCardinality(String),
+    metadata String,
+    created_at DateTime64(3),
+    timestamp DateTime64(3)
+) ENGINE = MergeTree
+PARTITION BY toYYYYMM(timestamp)
+ORDER BY (event_type, timestamp, event_id);
+
+CREATE TABLE IF NOT EXISTS customer_events (
+    event_id String,
+    event_type LowCardinality(String),
+    customer_id String,
+    email String,
+    name String,
+    phone String,
+    description String,
+    metadata String,
+    created_at DateTime64(3),
+    timestamp DateTime64(3)
+) ENGINE = MergeTree
+PARTITION BY toYYYYMM(timestamp)
+ORDER BY (event_type, timestamp, event_id);
+
+CREATE TABLE IF NOT EXISTS payment_method_events (
+    event_id String,
+    event_type LowCardinality(String),
+    payment_method_id String,
+    customer_id String,
+    type LowCardinality(String),
+    card_last4 String,
+    card_brand LowCardinality(String),
+    card_exp_month UInt8,
+    card_exp_year UInt16,
+    metadata String,
+    created_at DateTime64(3),
+    timestamp DateTime64(3)
+) ENGINE = MergeTree
+PARTITION BY toYYYYMM(timestamp)
+ORDER BY (event_type, timestamp, event_id);
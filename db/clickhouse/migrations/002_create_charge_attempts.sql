@@ -0,0 +1,14 @@
+-- Migration to create the charge attempts smart routing measures
+-- authorization rates over. Attempts are kept for 90 days.
+
+CREATE TABLE IF NOT EXISTS charge_attempts (
+    provider LowCardinality(String),
+    currency LowCardinality(String),
+    bin_country LowCardinality(String),
+    amount Int64,
+    authorized UInt8,
+    attempted_at DateTime64(3)
+) ENGINE = MergeTree
+PARTITION BY toYYYYMM(attempted_at)
+ORDER BY (provider, currency, bin_country, attempted_at)
+TTL toDateTime(attempted_at) + INTERVAL 90 DAY;
@@ -0,0 +1,7 @@
+-- Migration to add charge details to payment events
+-- The payment method and failure code let declines be broken down.
+
+ALTER TABLE payment_events
+    ADD COLUMN IF NOT EXISTS payment_method_id String AFTER customer_id,
+    ADD COLUMN IF NOT EXISTS description String AFTER status,
+    ADD COLUMN IF NOT EXISTS failure_code LowCardinality(String) AFTER description;
@@ -0,0 +1,67 @@
+-- Migration to create the refund, dispute, subscription and payout event
+-- tables
+
+CREATE TABLE IF NOT EXISTS refund_events (
+    event_id String,
+    event_type LowCardinality(String),
+    refund_id String,
+    charge_id String,
+    amount Int64,
+    currency LowCardinality(String),
+    status LowCardinality(String),
+    reason LowCardinality(String),
+    metadata String,
+    created_at DateTime64(3),
+    timestamp DateTime64(3)
+) ENGINE = MergeTree
+PARTITION BY toYYYYMM(timestamp)
+ORDER BY (event_type, timestamp, event_id);
+
+CREATE TABLE IF NOT EXISTS dispute_events (
+    event_id String,
+    event_type LowCardinality(String),
+    dispute_id String,
+    charge_id String,
+    amount Int64,
+    currency LowCardinality(String),
+    status LowCardinality(String),
+    reason LowCardinality(String),
+    network_reason_code String,
+    metadata String,
+    created_at DateTime64(3),
+    timestamp DateTime64(3)
+) ENGINE = MergeTree
+PARTITION BY toYYYYMM(timestamp)
+ORDER BY (event_type, timestamp, event_id);
+
+CREATE TABLE IF NOT EXISTS subscription_events (
+    event_id String,
+    event_type LowCardinality(String),
+    subscription_id String,
+    customer_id String,
+    price_id String,
+    quantity Int64,
+    status LowCardinality(String),
+    cancel_at_period_end UInt8,
+    current_period_start DateTime64(3),
+    current_period_end DateTime64(3),
+    metadata String,
+    created_at DateTime64(3),
+    timestamp DateTime64(3)
+) ENGINE = MergeTree
+PARTITION BY toYYYYMM(timestamp)
+ORDER BY (event_type, timestamp, event_id);
+
+CREATE TABLE IF NOT EXISTS payout_events (
+    event_id String,
+    event_type LowCardinality(String),
+    payout_id String,
+    amount Int64,
+    currency LowCardinality(String),
+    status LowCardinality(String),
+    arrival_date DateTime64(3),
+    created_at DateTime64(3),
+    timestamp DateTime64(3)
+) ENGINE = MergeTree
+PARTITION BY toYYYYMM(timestamp)
+ORDER BY (event_type, timestamp, event_id);
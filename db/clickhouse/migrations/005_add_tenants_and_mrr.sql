@@ -0,0 +1,12 @@
+-- Migration to scope charge and subscription events to tenants and record
+-- each subscription's monthly recurring revenue
+-- Tenants are taken from the tenant_id metadata of the charge or
+-- subscription; refunds and disputes belong to the tenant of their charge.
+
+ALTER TABLE payment_events
+    ADD COLUMN IF NOT EXISTS tenant_id LowCardinality(String) DEFAULT 'default' AFTER event_type;
+
+ALTER TABLE subscription_events
+    ADD COLUMN IF NOT EXISTS tenant_id LowCardinality(String) DEFAULT 'default' AFTER event_type,
+    ADD COLUMN IF NOT EXISTS currency LowCardinality(String) AFTER quantity,
+    ADD COLUMN IF NOT EXISTS mrr Int64 AFTER currency;
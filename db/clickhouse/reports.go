@@ -0,0 +1,224 @@
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"apis/payments/services/analytics"
+)
+
+// bucketExpressions truncate a column to the start of its bucket in UTC,
+// matching analytics.Granularity.Truncate
+var bucketExpressions = map[analytics.Granularity]string{
+	analytics.GranularityDay:   "toStartOfDay(%s, 'UTC')",
+	analytics.GranularityWeek:  "toDateTime(toMonday(%s, 'UTC'), 'UTC')",
+	analytics.GranularityMonth: "toDateTime(toStartOfMonth(%s, 'UTC'), 'UTC')",
+}
+
+// settledCharges selects a tenant's charges created in a range with their
+// outcome. A charge settles once, as succeeded or failed; replayed events
+// are collapsed to the charge's latest.
+const settledCharges = `
+	SELECT
+		charge_id,
+		argMax(currency, timestamp) AS charge_currency,
+		argMax(status, timestamp) AS charge_status,
+		argMax(amount, timestamp) AS charge_amount,
+		argMax(failure_code, timestamp) AS charge_failure_code,
+		min(created_at) AS charged_at
+	FROM payment_events
+	WHERE tenant_id = ?
+	AND event_type IN ('charge_succeeded', 'charge_failed')
+	AND created_at >= ? AND created_at < ?
+	GROUP BY charge_id
+`
+
+// ChargeBuckets counts a tenant's charges by the bucket they were created in
+func (a *AnalyticsService) ChargeBuckets(ctx context.Context, query *analytics.Query) ([]*analytics.ChargeBucket, error) {
+	ctx, span := a.tracer.Start(ctx, "AnalyticsService.ChargeBuckets")
+	defer span.End()
+
+	sql := fmt.Sprintf(`
+		SELECT
+			%s AS bucket,
+			charge_currency AS currency,
+			count() AS charges,
+			countIf(charge_status = 'succeeded') AS succeeded,
+			countIf(charge_status = 'failed') AS failed,
+			sumIf(charge_amount, charge_status = 'succeeded') AS volume
+		FROM (%s)
+		WHERE (? = '' OR charge_currency = ?)
+		GROUP BY bucket, currency
+	`, bucketExpression(query, "charged_at"), settledCharges)
+
+	var rows []struct {
+		Bucket    time.Time `ch:"bucket"`
+		Currency  string    `ch:"currency"`
+		Charges   uint64    `ch:"charges"`
+		Succeeded uint64    `ch:"succeeded"`
+		Failed    uint64    `ch:"failed"`
+		Volume    int64     `ch:"volume"`
+	}
+	err := a.conn.Select(ctx, &rows, sql,
+		query.TenantID, query.From, query.To,
+		query.Currency, query.Currency,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query charge buckets: %w", err)
+	}
+
+	buckets := make([]*analytics.ChargeBucket, len(rows))
+	for i, row := range rows {
+		buckets[i] = &analytics.ChargeBucket{
+			Start:     row.Bucket.UTC(),
+			Currency:  row.Currency,
+			Charges:   int64(row.Charges),
+			Succeeded: int64(row.Succeeded),
+			Failed:    int64(row.Failed),
+			Volume:    row.Volume,
+		}
+	}
+
+	return buckets, nil
+}
+
+// RefundBuckets totals a tenant's succeeded refunds by the bucket they were
+// created in. Refunds belong to the tenant of their charge.
+func (a *AnalyticsService) RefundBuckets(ctx context.Context, query *analytics.Query) ([]*analytics.RefundBucket, error) {
+	ctx, span := a.tracer.Start(ctx, "AnalyticsService.RefundBuckets")
+	defer span.End()
+
+	sql := fmt.Sprintf(`
+		SELECT
+			%s AS bucket,
+			refund_currency AS currency,
+			count() AS refunds,
+			sum(refund_amount) AS amount
+		FROM (
+			SELECT
+				refund_id,
+				argMax(currency, timestamp) AS refund_currency,
+				argMax(status, timestamp) AS refund_status,
+				argMax(amount, timestamp) AS refund_amount,
+				min(created_at) AS refunded_at
+			FROM refund_events
+			WHERE charge_id IN (SELECT charge_id FROM payment_events WHERE tenant_id = ?)
+			AND created_at >= ? AND created_at < ?
+			GROUP BY refund_id
+		)
+		WHERE refund_status = 'succeeded'
+		AND (? = '' OR refund_currency = ?)
+		GROUP BY bucket, currency
+	`, bucketExpression(query, "refunded_at"))
+
+	var rows []struct {
+		Bucket   time.Time `ch:"bucket"`
+		Currency string    `ch:"currency"`
+		Refunds  uint64    `ch:"refunds"`
+		Amount   int64     `ch:"amount"`
+	}
+	err := a.conn.Select(ctx, &rows, sql,
+		query.TenantID, query.From, query.To,
+		query.Currency, query.Currency,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query refund buckets: %w", err)
+	}
+
+	buckets := make([]*analytics.RefundBucket, len(rows))
+	for i, row := range rows {
+		buckets[i] = &analytics.RefundBucket{
+			Start:    row.Bucket.UTC(),
+			Currency: row.Currency,
+			Refunds:  int64(row.Refunds),
+			Amount:   row.Amount,
+		}
+	}
+
+	return buckets, nil
+}
+
+// SubscriptionChanges lists every change to a tenant's subscriptions before
+// the end of the range, oldest first
+func (a *AnalyticsService) SubscriptionChanges(ctx context.Context, query *analytics.Query) ([]*analytics.SubscriptionChange, error) {
+	ctx, span := a.tracer.Start(ctx, "AnalyticsService.SubscriptionChanges")
+	defer span.End()
+
+	sql := `
+		SELECT subscription_id, status, currency, mrr, timestamp
+		FROM subscription_events
+		WHERE tenant_id = ?
+		AND timestamp < ?
+		AND (? = '' OR currency = ?)
+		ORDER BY timestamp, event_id
+	`
+
+	var rows []struct {
+		SubscriptionID string    `ch:"subscription_id"`
+		Status         string    `ch:"status"`
+		Currency       string    `ch:"currency"`
+		MRR            int64     `ch:"mrr"`
+		Timestamp      time.Time `ch:"timestamp"`
+	}
+	err := a.conn.Select(ctx, &rows, sql, query.TenantID, query.To, query.Currency, query.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query subscription changes: %w", err)
+	}
+
+	changes := make([]*analytics.SubscriptionChange, len(rows))
+	for i, row := range rows {
+		changes[i] = &analytics.SubscriptionChange{
+			SubscriptionID: row.SubscriptionID,
+			Status:         row.Status,
+			Currency:       row.Currency,
+			MRR:            row.MRR,
+			At:             row.Timestamp.UTC(),
+		}
+	}
+
+	return changes, nil
+}
+
+// DeclineReasons counts a tenant's failed charges created in the range by
+// failure code
+func (a *AnalyticsService) DeclineReasons(ctx context.Context, query *analytics.Query) ([]*analytics.DeclineReason, error) {
+	ctx, span := a.tracer.Start(ctx, "AnalyticsService.DeclineReasons")
+	defer span.End()
+
+	sql := fmt.Sprintf(`
+		SELECT charge_failure_code AS code, count() AS count
+		FROM (%s)
+		WHERE charge_status = 'failed'
+		AND (? = '' OR charge_currency = ?)
+		GROUP BY code
+	`, settledCharges)
+
+	var rows []struct {
+		Code  string `ch:"code"`
+		Count uint64 `ch:"count"`
+	}
+	err := a.conn.Select(ctx, &rows, sql,
+		query.TenantID, query.From, query.To,
+		query.Currency, query.Currency,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query decline reasons: %w", err)
+	}
+
+	reasons := make([]*analytics.DeclineReason, len(rows))
+	for i, row := range rows {
+		reasons[i] = &analytics.DeclineReason{Code: row.Code, Count: int64(row.Count)}
+	}
+
+	return reasons, nil
+}
+
+// bucketExpression truncates a column to the query's buckets
+func bucketExpression(query *analytics.Query, column string) string {
+	expression, ok := bucketExpressions[query.Granularity]
+	if !ok {
+		expression = bucketExpressions[analytics.GranularityDay]
+	}
+	return fmt.Sprintf(expression, column)
+}
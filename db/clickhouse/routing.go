@@ -0,0 +1,106 @@
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"apis/payments/services/smartrouting"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RoutingStats stores gateway charge attempts in ClickHouse and reports
+// provider authorization rates over them for smart routing
+type RoutingStats struct {
+	conn   clickhouse.Conn
+	tracer trace.Tracer
+}
+
+// NewRoutingStats creates a new routing stats store
+func NewRoutingStats(conn clickhouse.Conn) *RoutingStats {
+	return &RoutingStats{
+		conn:   conn,
+		tracer: otel.Tracer("payments.analytics"),
+	}
+}
+
+// RecordChargeAttempt stores the outcome of a charge attempt at a provider
+func (r *RoutingStats) RecordChargeAttempt(ctx context.Context, attempt *smartrouting.Attempt) error {
+	ctx, span := r.tracer.Start(ctx, "RoutingStats.RecordChargeAttempt")
+	defer span.End()
+
+	query := `
+		INSERT INTO charge_attempts (
+			provider, currency, bin_country, amount, authorized, attempted_at
+		) VALUES (
+			?, ?, ?, ?, ?, ?
+		)
+	`
+
+	var authorized uint8
+	if attempt.Authorized {
+		authorized = 1
+	}
+
+	err := r.conn.Exec(ctx, query,
+		attempt.Provider,
+		attempt.Currency,
+		attempt.BINCountry,
+		attempt.Amount,
+		authorized,
+		attempt.AttemptedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to record charge attempt to ClickHouse: %w", err)
+	}
+
+	return nil
+}
+
+// AuthorizationRates counts attempts and authorizations per provider,
+// currency and card BIN country since a time
+func (r *RoutingStats) AuthorizationRates(ctx context.Context, since time.Time) ([]*smartrouting.AuthRate, error) {
+	ctx, span := r.tracer.Start(ctx, "RoutingStats.AuthorizationRates")
+	defer span.End()
+
+	query := `
+		SELECT
+			provider,
+			currency,
+			bin_country,
+			count() AS attempts,
+			countIf(authorized = 1) AS authorized
+		FROM charge_attempts
+		WHERE attempted_at >= ?
+		GROUP BY provider, currency, bin_country
+	`
+
+	rows, err := r.conn.Query(ctx, query, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query authorization rates: %w", err)
+	}
+	defer rows.Close()
+
+	var rates []*smartrouting.AuthRate
+	for rows.Next() {
+		var (
+			rate                 smartrouting.AuthRate
+			attempts, authorized uint64
+		)
+		if err := rows.Scan(&rate.Provider, &rate.Currency, &rate.BINCountry, &attempts, &authorized); err != nil {
+			return nil, fmt.Errorf("failed to scan authorization rate: %w", err)
+		}
+		rate.Attempts = int64(attempts)
+		rate.Authorized = int64(authorized)
+		rates = append(rates, &rate)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read authorization rates: %w", err)
+	}
+
+	return rates, nil
+}
@@ -0,0 +1,139 @@
+package clickhouse
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"apis/payments/services/analytics"
+	"apis/payments/services/stripe"
+
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// Provider events logged to each analytics table
+var (
+	chargeEvents = []stripego.EventType{
+		stripego.EventTypeChargePending,
+		stripego.EventTypeChargeSucceeded,
+		stripego.EventTypeChargeFailed,
+		stripego.EventTypeChargeCaptured,
+		stripego.EventTypeChargeExpired,
+		stripego.EventTypeChargeRefunded,
+	}
+	customerEvents = []stripego.EventType{
+		stripego.EventTypeCustomerCreated,
+		stripego.EventTypeCustomerUpdated,
+		stripego.EventTypeCustomerDeleted,
+	}
+	paymentMethodEvents = []stripego.EventType{
+		stripego.EventTypePaymentMethodAttached,
+		stripego.EventTypePaymentMethodUpdated,
+		stripego.EventTypePaymentMethodAutomaticallyUpdated,
+		stripego.EventTypePaymentMethodDetached,
+	}
+	refundEvents = []stripego.EventType{
+		stripego.EventTypeRefundCreated,
+		stripego.EventTypeRefundUpdated,
+	}
+	disputeEvents = []stripego.EventType{
+		stripego.EventTypeChargeDisputeCreated,
+		stripego.EventTypeChargeDisputeUpdated,
+		stripego.EventTypeChargeDisputeClosed,
+	}
+	subscriptionEvents = []stripego.EventType{
+		stripego.EventTypeCustomerSubscriptionCreated,
+		stripego.EventTypeCustomerSubscriptionUpdated,
+		stripego.EventTypeCustomerSubscriptionDeleted,
+	}
+	payoutEvents = []stripego.EventType{
+		stripego.EventTypePayoutCreated,
+		stripego.EventTypePayoutPaid,
+		stripego.EventTypePayoutFailed,
+		stripego.EventTypePayoutCanceled,
+	}
+)
+
+// SkipRelayedEvents stops RegisterWebhookHandlers logging the charge,
+// refund and dispute events the relay publishes, for when an Ingester
+// consumes them from the events topic instead
+func (a *AnalyticsService) SkipRelayedEvents() {
+	a.skipRelayed = true
+}
+
+// RegisterWebhookHandlers logs provider events to the analytics tables,
+// named after the provider event, e.g. charge.succeeded as
+// charge_succeeded. Analytics are best effort, so an event that can't be
+// logged never fails its webhook.
+func (a *AnalyticsService) RegisterWebhookHandlers(webhooks *stripe.WebhookService) {
+	if !a.skipRelayed {
+		logEvents(webhooks, chargeEvents, func(ctx context.Context, eventType string, charge *stripego.Charge) error {
+			return a.LogChargeEvent(ctx, eventType, stripe.ConvertCharge(charge))
+		})
+		logEvents(webhooks, refundEvents, func(ctx context.Context, eventType string, refund *stripego.Refund) error {
+			return a.LogRefundEvent(ctx, eventType, stripe.ConvertRefund(refund))
+		})
+		logEvents(webhooks, disputeEvents, func(ctx context.Context, eventType string, dispute *stripego.Dispute) error {
+			return a.LogDisputeEvent(ctx, eventType, stripe.ConvertDispute(dispute))
+		})
+	}
+	logEvents(webhooks, customerEvents, func(ctx context.Context, eventType string, customer *stripego.Customer) error {
+		return a.LogCustomerEvent(ctx, eventType, stripe.ConvertCustomer(customer))
+	})
+	logEvents(webhooks, paymentMethodEvents, func(ctx context.Context, eventType string, paymentMethod *stripego.PaymentMethod) error {
+		return a.LogPaymentMethodEvent(ctx, eventType, stripe.ConvertPaymentMethod(paymentMethod))
+	})
+	logEvents(webhooks, subscriptionEvents, func(ctx context.Context, eventType string, subscription *stripego.Subscription) error {
+		currency, mrr := subscriptionMRR(subscription)
+		return a.LogSubscriptionEvent(ctx, eventType, stripe.ConvertSubscription(subscription), currency, mrr)
+	})
+	logEvents(webhooks, payoutEvents, func(ctx context.Context, eventType string, payout *stripego.Payout) error {
+		return a.LogPayoutEvent(ctx, eventType, stripe.ConvertPayout(payout))
+	})
+}
+
+// logEvents logs the object of every event of eventTypes with logEvent
+func logEvents[T any](webhooks *stripe.WebhookService, eventTypes []stripego.EventType, logEvent func(ctx context.Context, eventType string, object *T) error) {
+	for _, eventType := range eventTypes {
+		webhooks.On(eventType, func(ctx context.Context, event stripego.Event) error {
+			var object T
+			if err := json.Unmarshal(event.Data.Raw, &object); err != nil {
+				return fmt.Errorf("failed to parse %s: %w", event.Type, err)
+			}
+
+			if err := logEvent(ctx, eventTypeName(event.Type), &object); err != nil {
+				log.Printf("Failed to log %s event %s: %v", event.Type, event.ID, err)
+			}
+			return nil
+		})
+	}
+}
+
+// eventTypeName names an analytics event after its provider event, e.g.
+// charge_succeeded for charge.succeeded
+func eventTypeName(eventType stripego.EventType) string {
+	return strings.ReplaceAll(string(eventType), ".", "_")
+}
+
+// subscriptionMRR totals the monthly recurring revenue of a subscription's
+// items. Metered prices are billed on usage, so they add none.
+func subscriptionMRR(subscription *stripego.Subscription) (string, int64) {
+	var currency string
+	var mrr int64
+	if subscription.Items == nil {
+		return currency, mrr
+	}
+
+	for _, item := range subscription.Items.Data {
+		price := item.Price
+		if price == nil || price.Recurring == nil || price.Recurring.UsageType == stripego.PriceRecurringUsageTypeMetered {
+			continue
+		}
+		currency = string(price.Currency)
+		mrr += analytics.MonthlyAmount(price.UnitAmount, item.Quantity, string(price.Recurring.Interval), price.Recurring.IntervalCount)
+	}
+
+	return currency, mrr
+}
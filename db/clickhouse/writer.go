@@ -0,0 +1,230 @@
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"apis/payments/services/batching"
+	"apis/payments/services/config"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrQueueFull is returned when an event is written faster than the writer
+// flushes; the event is dropped rather than blocking the caller
+var ErrQueueFull = errors.New("analytics queue is full")
+
+// Config represents the analytics configuration
+type Config struct {
+	Enabled       bool
+	Batches       *batching.Limits // Bounds the rows inserted at once adapt within
+	FlushInterval time.Duration    // Longest a row waits to be inserted
+	QueueSize     int              // Rows waiting to be inserted before events are dropped
+	FromKafka     bool             // Ingest relayed events from the events topic rather than logging them from webhooks
+	ConsumerGroup string           // Consumer group ingesting the events topic
+}
+
+// DefaultBatchLimits returns the insert batch limits used when none are
+// configured. ClickHouse prefers few large inserts, so batches start larger
+// and grow faster than the batching defaults, and inserts run one at a time.
+func DefaultBatchLimits() *batching.Limits {
+	return &batching.Limits{
+		MinSize:        100,
+		MaxSize:        10000,
+		InitialSize:    1000,
+		Step:           100,
+		MinConcurrency: 1,
+		MaxConcurrency: 1,
+		TargetLatency:  2 * time.Second,
+		MaxErrorRate:   0,
+		Backoff:        0.5,
+	}
+}
+
+// LoadConfig loads the analytics configuration from environment variables
+func LoadConfig() *Config {
+	return &Config{
+		Enabled:       os.Getenv("ANALYTICS_ENABLED") == "true",
+		Batches:       batching.LoadLimitsFrom("ANALYTICS", DefaultBatchLimits()),
+		FlushInterval: time.Duration(config.Int("ANALYTICS_FLUSH_INTERVAL_MS", 1000)) * time.Millisecond,
+		QueueSize:     config.Int("ANALYTICS_QUEUE_SIZE", 10000),
+		FromKafka:     os.Getenv("ANALYTICS_FROM_KAFKA") == "true",
+		ConsumerGroup: getEnv("ANALYTICS_CONSUMER_GROUP", "payments-analytics"),
+	}
+}
+
+// Table is a ClickHouse table events are written to, with the columns
+// rows are given in
+type Table struct {
+	Name    string
+	Columns []string
+}
+
+// InsertFunc inserts rows into a table at once
+type InsertFunc func(ctx context.Context, table *Table, rows [][]any) error
+
+// BatchInsert inserts rows with a native batch on a connection
+func BatchInsert(conn driver.Conn) InsertFunc {
+	return func(ctx context.Context, table *Table, rows [][]any) error {
+		query := fmt.Sprintf("INSERT INTO %s (%s)", table.Name, strings.Join(table.Columns, ", "))
+		batch, err := conn.PrepareBatch(ctx, query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare batch: %w", err)
+		}
+
+		for _, row := range rows {
+			if err := batch.Append(row...); err != nil {
+				batch.Abort()
+				return fmt.Errorf("failed to append row: %w", err)
+			}
+		}
+
+		if err := batch.Send(); err != nil {
+			return fmt.Errorf("failed to send batch: %w", err)
+		}
+		return nil
+	}
+}
+
+// Writer queues rows and inserts them in batches per table in the
+// background, so logging an event never waits on ClickHouse. Rows are
+// inserted once a table has a full batch, and at every flush interval.
+// Batch sizes adapt to how long inserts take and whether they fail.
+type Writer struct {
+	insert  InsertFunc
+	config  *Config
+	batches *batching.Controller
+	tracer  trace.Tracer
+
+	queue   chan queuedRow
+	dropped atomic.Int64
+}
+
+type queuedRow struct {
+	table  *Table
+	values []any
+}
+
+// NewWriter creates a writer inserting rows with insert
+func NewWriter(insert InsertFunc, config *Config) *Writer {
+	return &Writer{
+		insert:  insert,
+		config:  config,
+		batches: batching.NewController(config.Batches),
+		tracer:  otel.Tracer("payments.analytics"),
+		queue:   make(chan queuedRow, max(1, config.QueueSize)),
+	}
+}
+
+// Write queues a row for a table, with a value per column
+func (w *Writer) Write(table *Table, values ...any) error {
+	if len(values) != len(table.Columns) {
+		return fmt.Errorf("%s takes %d columns, got %d", table.Name, len(table.Columns), len(values))
+	}
+
+	select {
+	case w.queue <- queuedRow{table: table, values: values}:
+		return nil
+	default:
+		w.dropped.Add(1)
+		return ErrQueueFull
+	}
+}
+
+// Dropped returns the number of rows dropped because the queue was full
+// or their insert failed
+func (w *Writer) Dropped() int64 {
+	return w.dropped.Load()
+}
+
+// BatchStats returns the insert batch size the writer has adapted to
+func (w *Writer) BatchStats() batching.Stats {
+	return w.batches.Stats()
+}
+
+// Start inserts queued rows until stopped. Stopping inserts the rows still
+// queued before it returns.
+func (w *Writer) Start() (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(max(w.config.FlushInterval, time.Millisecond))
+		defer ticker.Stop()
+
+		pending := make(map[*Table][][]any)
+		for {
+			select {
+			case row := <-w.queue:
+				pending[row.table] = append(pending[row.table], row.values)
+				if len(pending[row.table]) >= w.batches.Size() {
+					w.flush(row.table, pending[row.table])
+					delete(pending, row.table)
+				}
+			case <-ticker.C:
+				w.flushAll(pending)
+			case <-done:
+				for {
+					select {
+					case row := <-w.queue:
+						pending[row.table] = append(pending[row.table], row.values)
+					default:
+						w.flushAll(pending)
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+		<-stopped
+	}
+}
+
+// flushAll inserts the pending rows of every table
+func (w *Writer) flushAll(pending map[*Table][][]any) {
+	for table, rows := range pending {
+		w.flush(table, rows)
+		delete(pending, table)
+	}
+}
+
+// flush inserts rows into a table, adapting the batch size to how the
+// insert went. Analytics are best effort, so rows that fail to insert are
+// logged and dropped.
+func (w *Writer) flush(table *Table, rows [][]any) {
+	ctx, span := w.tracer.Start(context.Background(), "Writer.flush")
+	defer span.End()
+
+	start := time.Now()
+	err := w.insert(ctx, table, rows)
+	result := batching.Result{Items: len(rows), Latency: time.Since(start)}
+	if err != nil {
+		result.Failures = len(rows)
+		w.dropped.Add(int64(len(rows)))
+		log.Printf("Failed to insert %d rows into %s: %v", len(rows), table.Name, err)
+	}
+	w.batches.Observe(result)
+}
+
+// getEnv gets an environment variable or returns a default value
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
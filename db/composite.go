@@ -0,0 +1,252 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/composite"
+)
+
+// CreateCompositeCharge stores a composite charge and its legs
+func (r *Repository) CreateCompositeCharge(ctx context.Context, charge *composite.Charge) (*composite.Charge, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.CreateCompositeCharge")
+	defer span.End()
+
+	params := sqlc.CreateCompositeChargeParams{
+		ID:             charge.ID,
+		TenantID:       charge.TenantID,
+		Amount:         charge.Amount,
+		Currency:       charge.Currency,
+		RefundStrategy: charge.RefundStrategy,
+	}
+
+	dbCharge, err := r.queries.CreateCompositeCharge(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create composite charge: %w", err)
+	}
+
+	created := convertCompositeCharge(dbCharge)
+	for i, leg := range charge.Legs {
+		legParams := sqlc.CreateCompositeChargeLegParams{
+			CompositeChargeID: charge.ID,
+			ChargeID:          leg.ChargeID,
+			Position:          int32(i),
+			Priority:          int32(leg.Priority),
+			Amount:            leg.Amount,
+		}
+
+		dbLeg, err := r.queries.CreateCompositeChargeLeg(ctx, r.db, legParams)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create composite charge leg: %w", err)
+		}
+		created.Legs = append(created.Legs, convertCompositeChargeLeg(dbLeg))
+	}
+
+	return created, nil
+}
+
+// GetCompositeCharge retrieves a composite charge with its legs
+func (r *Repository) GetCompositeCharge(ctx context.Context, id string) (*composite.Charge, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetCompositeCharge")
+	defer span.End()
+
+	dbCharge, err := r.queries.GetCompositeCharge(ctx, r.db, sqlc.GetCompositeChargeParams{
+		ID:       id,
+		TenantID: scopedTenant(ctx),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get composite charge: %w", err)
+	}
+
+	dbLegs, err := r.queries.ListCompositeChargeLegs(ctx, r.db, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list composite charge legs: %w", err)
+	}
+
+	charge := convertCompositeCharge(dbCharge)
+	for _, dbLeg := range dbLegs {
+		charge.Legs = append(charge.Legs, convertCompositeChargeLeg(dbLeg))
+	}
+
+	return charge, nil
+}
+
+// CreateCompositeRefund stores a composite refund and the split of its legs
+func (r *Repository) CreateCompositeRefund(ctx context.Context, refund *composite.Refund) (*composite.Refund, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.CreateCompositeRefund")
+	defer span.End()
+
+	params := sqlc.CreateCompositeRefundParams{
+		ID:                refund.ID,
+		CompositeChargeID: refund.CompositeChargeID,
+		TenantID:          refund.TenantID,
+		Amount:            refund.Amount,
+		Currency:          refund.Currency,
+		Reason:            refund.Reason,
+		Status:            refund.Status,
+	}
+
+	dbRefund, err := r.queries.CreateCompositeRefund(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create composite refund: %w", err)
+	}
+
+	created := convertCompositeRefund(dbRefund)
+	for i, leg := range refund.Legs {
+		legParams := sqlc.CreateCompositeRefundLegParams{
+			CompositeRefundID: refund.ID,
+			ChargeID:          leg.ChargeID,
+			Position:          int32(i),
+			Amount:            leg.Amount,
+			Status:            leg.Status,
+		}
+
+		dbLeg, err := r.queries.CreateCompositeRefundLeg(ctx, r.db, legParams)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create composite refund leg: %w", err)
+		}
+		created.Legs = append(created.Legs, convertCompositeRefundLeg(dbLeg))
+	}
+
+	return created, nil
+}
+
+// GetCompositeRefund retrieves a composite refund with its legs
+func (r *Repository) GetCompositeRefund(ctx context.Context, id string) (*composite.Refund, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetCompositeRefund")
+	defer span.End()
+
+	dbRefund, err := r.queries.GetCompositeRefund(ctx, r.db, sqlc.GetCompositeRefundParams{
+		ID:       id,
+		TenantID: scopedTenant(ctx),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get composite refund: %w", err)
+	}
+
+	return r.withCompositeRefundLegs(ctx, convertCompositeRefund(dbRefund))
+}
+
+// ListCompositeRefunds retrieves the refunds of a composite charge with their legs
+func (r *Repository) ListCompositeRefunds(ctx context.Context, compositeChargeID string) ([]*composite.Refund, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListCompositeRefunds")
+	defer span.End()
+
+	dbRefunds, err := r.queries.ListCompositeRefunds(ctx, r.db, sqlc.ListCompositeRefundsParams{
+		CompositeChargeID: compositeChargeID,
+		TenantID:          scopedTenant(ctx),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list composite refunds: %w", err)
+	}
+
+	refunds := make([]*composite.Refund, len(dbRefunds))
+	for i, dbRefund := range dbRefunds {
+		refund, err := r.withCompositeRefundLegs(ctx, convertCompositeRefund(dbRefund))
+		if err != nil {
+			return nil, err
+		}
+		refunds[i] = refund
+	}
+
+	return refunds, nil
+}
+
+// UpdateCompositeRefundLeg records the provider refund and status of a leg
+func (r *Repository) UpdateCompositeRefundLeg(ctx context.Context, refundID string, leg *composite.LegRefund) (*composite.LegRefund, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.UpdateCompositeRefundLeg")
+	defer span.End()
+
+	params := sqlc.UpdateCompositeRefundLegParams{
+		CompositeRefundID: refundID,
+		ChargeID:          leg.ChargeID,
+		RefundID:          leg.RefundID,
+		ApprovalID:        leg.ApprovalID,
+		Status:            leg.Status,
+		FailureReason:     leg.FailureReason,
+	}
+
+	dbLeg, err := r.queries.UpdateCompositeRefundLeg(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update composite refund leg: %w", err)
+	}
+
+	return convertCompositeRefundLeg(dbLeg), nil
+}
+
+// UpdateCompositeRefundStatus records the combined status of a composite refund
+func (r *Repository) UpdateCompositeRefundStatus(ctx context.Context, id, status string) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.UpdateCompositeRefundStatus")
+	defer span.End()
+
+	params := sqlc.UpdateCompositeRefundStatusParams{ID: id, Status: status}
+	if err := r.queries.UpdateCompositeRefundStatus(ctx, r.db, params); err != nil {
+		return fmt.Errorf("failed to update composite refund status: %w", err)
+	}
+
+	return nil
+}
+
+// withCompositeRefundLegs loads the legs of a composite refund
+func (r *Repository) withCompositeRefundLegs(ctx context.Context, refund *composite.Refund) (*composite.Refund, error) {
+	dbLegs, err := r.queries.ListCompositeRefundLegs(ctx, r.db, refund.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list composite refund legs: %w", err)
+	}
+
+	for _, dbLeg := range dbLegs {
+		refund.Legs = append(refund.Legs, convertCompositeRefundLeg(dbLeg))
+	}
+
+	return refund, nil
+}
+
+// convertCompositeCharge converts a database composite charge without its legs
+func convertCompositeCharge(dbCharge sqlc.CompositeCharge) *composite.Charge {
+	return &composite.Charge{
+		ID:             dbCharge.ID,
+		TenantID:       dbCharge.TenantID,
+		Amount:         dbCharge.Amount,
+		Currency:       dbCharge.Currency,
+		RefundStrategy: dbCharge.RefundStrategy,
+		CreatedAt:      dbCharge.CreatedAt.Time,
+	}
+}
+
+// convertCompositeChargeLeg converts a database composite charge leg
+func convertCompositeChargeLeg(dbLeg sqlc.CompositeChargeLeg) *composite.Leg {
+	return &composite.Leg{
+		ChargeID: dbLeg.ChargeID,
+		Amount:   dbLeg.Amount,
+		Priority: int(dbLeg.Priority),
+	}
+}
+
+// convertCompositeRefund converts a database composite refund without its legs
+func convertCompositeRefund(dbRefund sqlc.CompositeRefund) *composite.Refund {
+	return &composite.Refund{
+		ID:                dbRefund.ID,
+		CompositeChargeID: dbRefund.CompositeChargeID,
+		TenantID:          dbRefund.TenantID,
+		Amount:            dbRefund.Amount,
+		Currency:          dbRefund.Currency,
+		Reason:            dbRefund.Reason,
+		Status:            dbRefund.Status,
+		CreatedAt:         dbRefund.CreatedAt.Time,
+		UpdatedAt:         dbRefund.UpdatedAt.Time,
+	}
+}
+
+// convertCompositeRefundLeg converts a database composite refund leg
+func convertCompositeRefundLeg(dbLeg sqlc.CompositeRefundLeg) *composite.LegRefund {
+	return &composite.LegRefund{
+		ChargeID:      dbLeg.ChargeID,
+		Amount:        dbLeg.Amount,
+		RefundID:      dbLeg.RefundID,
+		ApprovalID:    dbLeg.ApprovalID,
+		Status:        dbLeg.Status,
+		FailureReason: dbLeg.FailureReason,
+		UpdatedAt:     dbLeg.UpdatedAt.Time,
+	}
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/stripe"
+)
+
+// RecordChargeCredential stores the credential a charge was authorized with
+func (r *Repository) RecordChargeCredential(ctx context.Context, credential *stripe.ChargeCredential) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.RecordChargeCredential")
+	defer span.End()
+
+	params := sqlc.RecordChargeCredentialParams{
+		ChargeID:        credential.ChargeID,
+		TenantID:        credential.TenantID,
+		CustomerID:      credential.CustomerID,
+		PaymentMethodID: credential.PaymentMethodID,
+		Network:         credential.Network,
+		CredentialType:  credential.CredentialType,
+		Wallet:          credential.Wallet,
+		Amount:          credential.Amount,
+		Currency:        credential.Currency,
+		Status:          credential.Status,
+		FailureCode:     credential.FailureCode,
+	}
+
+	if err := r.queries.RecordChargeCredential(ctx, r.db, params); err != nil {
+		return fmt.Errorf("failed to record charge credential: %w", err)
+	}
+
+	return nil
+}
+
+// GetChargeCredentialStats aggregates a tenant's charges in one currency by credential type, network and wallet
+func (r *Repository) GetChargeCredentialStats(ctx context.Context, tenantID, currency string, since time.Time) ([]*stripe.CredentialStats, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetChargeCredentialStats")
+	defer span.End()
+
+	rows, err := r.queries.GetChargeCredentialStats(ctx, r.db, sqlc.GetChargeCredentialStatsParams{
+		TenantID:  tenantID,
+		Currency:  currency,
+		CreatedAt: sql.NullTime{Time: since, Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get charge credential stats: %w", err)
+	}
+
+	stats := make([]*stripe.CredentialStats, len(rows))
+	for i, row := range rows {
+		stats[i] = &stripe.CredentialStats{
+			CredentialType:  row.CredentialType,
+			Network:         row.Network,
+			Wallet:          row.Wallet,
+			ChargeCount:     row.ChargeCount,
+			SucceededCount:  row.SucceededCount,
+			SucceededAmount: row.SucceededAmount,
+		}
+		if row.ChargeCount > 0 {
+			stats[i].ApprovalRate = float64(row.SucceededCount) / float64(row.ChargeCount)
+		}
+	}
+
+	return stats, nil
+}
+
+// ListTokenizedPaymentMethods returns the customer's payment methods that have
+// successfully authorized with a network token
+func (r *Repository) ListTokenizedPaymentMethods(ctx context.Context, customerID string) ([]string, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListTokenizedPaymentMethods")
+	defer span.End()
+
+	ids, err := r.queries.ListTokenizedPaymentMethods(ctx, r.db, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tokenized payment methods: %w", err)
+	}
+
+	return ids, nil
+}
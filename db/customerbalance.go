@@ -0,0 +1,197 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/customerbalance"
+)
+
+// GetCustomerBalance retrieves a customer's balance in one currency
+func (r *Repository) GetCustomerBalance(ctx context.Context, tenantID, customerID, currency string) (*customerbalance.Balance, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetCustomerBalance")
+	defer span.End()
+
+	dbBalance, err := r.queries.GetCustomerBalance(ctx, r.db, sqlc.GetCustomerBalanceParams{
+		TenantID:   tenantID,
+		CustomerID: customerID,
+		Currency:   currency,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer balance: %w", err)
+	}
+
+	return convertCustomerBalance(dbBalance), nil
+}
+
+// ListCustomerBalances retrieves a customer's balance in each currency
+func (r *Repository) ListCustomerBalances(ctx context.Context, tenantID, customerID string) ([]*customerbalance.Balance, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListCustomerBalances")
+	defer span.End()
+
+	dbBalances, err := r.queries.ListCustomerBalances(ctx, r.db, sqlc.ListCustomerBalancesParams{
+		TenantID:   tenantID,
+		CustomerID: customerID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list customer balances: %w", err)
+	}
+
+	balances := make([]*customerbalance.Balance, len(dbBalances))
+	for i, dbBalance := range dbBalances {
+		balances[i] = convertCustomerBalance(dbBalance)
+	}
+
+	return balances, nil
+}
+
+// AdjustCustomerBalance adds to a customer's balance in one currency,
+// returning sql.ErrNoRows rather than leaving it negative
+func (r *Repository) AdjustCustomerBalance(ctx context.Context, tenantID, customerID, currency string, amount int64) (*customerbalance.Balance, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.AdjustCustomerBalance")
+	defer span.End()
+
+	dbBalance, err := r.queries.AdjustCustomerBalance(ctx, r.db, sqlc.AdjustCustomerBalanceParams{
+		TenantID:   tenantID,
+		CustomerID: customerID,
+		Currency:   currency,
+		Amount:     amount,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to adjust customer balance: %w", err)
+	}
+
+	return convertCustomerBalance(dbBalance), nil
+}
+
+// CreateCustomerBalanceTransaction stores a movement of a customer's balance
+func (r *Repository) CreateCustomerBalanceTransaction(ctx context.Context, transaction *customerbalance.Transaction) (*customerbalance.Transaction, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.CreateCustomerBalanceTransaction")
+	defer span.End()
+
+	dbTransaction, err := r.queries.CreateCustomerBalanceTransaction(ctx, r.db, sqlc.CreateCustomerBalanceTransactionParams{
+		ID:                    transaction.ID,
+		TenantID:              transaction.TenantID,
+		CustomerID:            transaction.CustomerID,
+		Type:                  transaction.Type,
+		Reason:                transaction.Reason,
+		Amount:                transaction.Amount,
+		Currency:              transaction.Currency,
+		BalanceAfter:          transaction.BalanceAfter,
+		Description:           transaction.Description,
+		SourceID:              transaction.SourceID,
+		ProviderTransactionID: transaction.ProviderTransactionID,
+		CreatedBy:             transaction.CreatedBy,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create customer balance transaction: %w", err)
+	}
+
+	return convertCustomerBalanceTransaction(dbTransaction), nil
+}
+
+// ListCustomerBalanceTransactions retrieves a customer's latest balance
+// transactions, newest first
+func (r *Repository) ListCustomerBalanceTransactions(ctx context.Context, tenantID, customerID string, limit int) ([]*customerbalance.Transaction, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListCustomerBalanceTransactions")
+	defer span.End()
+
+	dbTransactions, err := r.queries.ListCustomerBalanceTransactions(ctx, r.db, sqlc.ListCustomerBalanceTransactionsParams{
+		TenantID:   tenantID,
+		CustomerID: customerID,
+		Limit:      int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list customer balance transactions: %w", err)
+	}
+
+	transactions := make([]*customerbalance.Transaction, len(dbTransactions))
+	for i, dbTransaction := range dbTransactions {
+		transactions[i] = convertCustomerBalanceTransaction(dbTransaction)
+	}
+
+	return transactions, nil
+}
+
+// GetCustomerBalanceTransactionBySource retrieves the first balance
+// transaction of a type for a charge or invoice
+func (r *Repository) GetCustomerBalanceTransactionBySource(ctx context.Context, tenantID, transactionType, sourceID string) (*customerbalance.Transaction, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetCustomerBalanceTransactionBySource")
+	defer span.End()
+
+	dbTransaction, err := r.queries.GetCustomerBalanceTransactionBySource(ctx, r.db, sqlc.GetCustomerBalanceTransactionBySourceParams{
+		TenantID: tenantID,
+		Type:     transactionType,
+		SourceID: sourceID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer balance transaction: %w", err)
+	}
+
+	return convertCustomerBalanceTransaction(dbTransaction), nil
+}
+
+// SumCustomerBalanceTransactionsBySource totals the balance transactions of
+// a type for a charge or invoice
+func (r *Repository) SumCustomerBalanceTransactionsBySource(ctx context.Context, tenantID, transactionType, sourceID string) (int64, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.SumCustomerBalanceTransactionsBySource")
+	defer span.End()
+
+	total, err := r.queries.SumCustomerBalanceTransactionsBySource(ctx, r.db, sqlc.SumCustomerBalanceTransactionsBySourceParams{
+		TenantID: tenantID,
+		Type:     transactionType,
+		SourceID: sourceID,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to sum customer balance transactions: %w", err)
+	}
+
+	return total, nil
+}
+
+// SetCustomerBalanceTransactionSource links a balance transaction with the
+// charge it was applied to
+func (r *Repository) SetCustomerBalanceTransactionSource(ctx context.Context, id, sourceID string) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.SetCustomerBalanceTransactionSource")
+	defer span.End()
+
+	err := r.queries.SetCustomerBalanceTransactionSource(ctx, r.db, sqlc.SetCustomerBalanceTransactionSourceParams{
+		ID:       id,
+		SourceID: sourceID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set customer balance transaction source: %w", err)
+	}
+
+	return nil
+}
+
+// convertCustomerBalance converts a database customer balance
+func convertCustomerBalance(dbBalance sqlc.CustomerBalance) *customerbalance.Balance {
+	return &customerbalance.Balance{
+		Currency:  dbBalance.Currency,
+		Amount:    dbBalance.Amount,
+		UpdatedAt: dbBalance.UpdatedAt.Time,
+	}
+}
+
+// convertCustomerBalanceTransaction converts a database customer balance
+// transaction
+func convertCustomerBalanceTransaction(dbTransaction sqlc.CustomerBalanceTransaction) *customerbalance.Transaction {
+	return &customerbalance.Transaction{
+		ID:                    dbTransaction.ID,
+		TenantID:              dbTransaction.TenantID,
+		CustomerID:            dbTransaction.CustomerID,
+		Type:                  dbTransaction.Type,
+		Reason:                dbTransaction.Reason,
+		Amount:                dbTransaction.Amount,
+		Currency:              dbTransaction.Currency,
+		BalanceAfter:          dbTransaction.BalanceAfter,
+		Description:           dbTransaction.Description,
+		SourceID:              dbTransaction.SourceID,
+		ProviderTransactionID: dbTransaction.ProviderTransactionID,
+		CreatedBy:             dbTransaction.CreatedBy,
+		CreatedAt:             dbTransaction.CreatedAt.Time,
+	}
+}
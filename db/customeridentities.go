@@ -0,0 +1,214 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/customers"
+)
+
+// CreateCustomerIdentity records the identity of a new customer
+func (r *Repository) CreateCustomerIdentity(ctx context.Context, identity *customers.Identity) (*customers.Identity, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.CreateCustomerIdentity")
+	defer span.End()
+
+	params := sqlc.CreateCustomerIdentityParams{
+		CustomerID:      identity.CustomerID,
+		TenantID:        identity.TenantID,
+		NormalizedEmail: identity.Email,
+		Name:            identity.Name,
+	}
+
+	dbIdentity, err := r.queries.CreateCustomerIdentity(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create customer identity: %w", err)
+	}
+
+	return convertCustomerIdentity(dbIdentity), nil
+}
+
+// GetCustomerIdentity retrieves a customer's identity
+func (r *Repository) GetCustomerIdentity(ctx context.Context, customerID string) (*customers.Identity, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetCustomerIdentity")
+	defer span.End()
+
+	dbIdentity, err := r.queries.GetCustomerIdentity(ctx, r.db, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer identity: %w", err)
+	}
+
+	return convertCustomerIdentity(dbIdentity), nil
+}
+
+// ListCustomerIdentitiesByEmail retrieves a tenant's customers with a normalized email
+func (r *Repository) ListCustomerIdentitiesByEmail(ctx context.Context, tenantID, email string) ([]*customers.Identity, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListCustomerIdentitiesByEmail")
+	defer span.End()
+
+	params := sqlc.ListCustomerIdentitiesByEmailParams{TenantID: tenantID, NormalizedEmail: email}
+	dbIdentities, err := r.queries.ListCustomerIdentitiesByEmail(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list customer identities: %w", err)
+	}
+
+	identities := make([]*customers.Identity, len(dbIdentities))
+	for i, dbIdentity := range dbIdentities {
+		identities[i] = convertCustomerIdentity(dbIdentity)
+	}
+
+	return identities, nil
+}
+
+// UpdateCustomerIdentity records a customer's new email, name and verification
+func (r *Repository) UpdateCustomerIdentity(ctx context.Context, customerID, email, name string, verifiedAt *time.Time) (*customers.Identity, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.UpdateCustomerIdentity")
+	defer span.End()
+
+	params := sqlc.UpdateCustomerIdentityParams{
+		CustomerID:      customerID,
+		NormalizedEmail: email,
+		Name:            name,
+	}
+	if verifiedAt != nil {
+		params.EmailVerifiedAt = sql.NullTime{Time: *verifiedAt, Valid: true}
+	}
+
+	dbIdentity, err := r.queries.UpdateCustomerIdentity(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update customer identity: %w", err)
+	}
+
+	return convertCustomerIdentity(dbIdentity), nil
+}
+
+// SetCustomerVerificationToken stores the hash of a customer's outstanding verification token
+func (r *Repository) SetCustomerVerificationToken(ctx context.Context, customerID, tokenHash string, expiresAt time.Time) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.SetCustomerVerificationToken")
+	defer span.End()
+
+	params := sqlc.SetCustomerVerificationTokenParams{
+		CustomerID:            customerID,
+		VerificationTokenHash: tokenHash,
+		VerificationExpiresAt: sql.NullTime{Time: expiresAt, Valid: true},
+	}
+
+	if err := r.queries.SetCustomerVerificationToken(ctx, r.db, params); err != nil {
+		return fmt.Errorf("failed to set customer verification token: %w", err)
+	}
+
+	return nil
+}
+
+// MarkCustomerEmailVerified marks a customer's email verified and clears its token
+func (r *Repository) MarkCustomerEmailVerified(ctx context.Context, customerID string, verifiedAt time.Time) (*customers.Identity, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.MarkCustomerEmailVerified")
+	defer span.End()
+
+	params := sqlc.MarkCustomerEmailVerifiedParams{
+		CustomerID:      customerID,
+		EmailVerifiedAt: sql.NullTime{Time: verifiedAt, Valid: true},
+	}
+
+	dbIdentity, err := r.queries.MarkCustomerEmailVerified(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark customer email verified: %w", err)
+	}
+
+	return convertCustomerIdentity(dbIdentity), nil
+}
+
+// DeleteCustomerIdentity removes a customer's identity
+func (r *Repository) DeleteCustomerIdentity(ctx context.Context, customerID string) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.DeleteCustomerIdentity")
+	defer span.End()
+
+	if err := r.queries.DeleteCustomerIdentity(ctx, r.db, customerID); err != nil {
+		return fmt.Errorf("failed to delete customer identity: %w", err)
+	}
+
+	return nil
+}
+
+// CreateCustomerReference maps an external reference to a customer. It
+// returns sql.ErrNoRows, wrapped, when the tenant already maps the reference.
+func (r *Repository) CreateCustomerReference(ctx context.Context, reference *customers.Reference) (*customers.Reference, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.CreateCustomerReference")
+	defer span.End()
+
+	params := sqlc.CreateCustomerReferenceParams{
+		TenantID:          reference.TenantID,
+		ExternalReference: reference.ExternalReference,
+		CustomerID:        reference.CustomerID,
+	}
+
+	dbReference, err := r.queries.CreateCustomerReference(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create customer reference: %w", err)
+	}
+
+	return convertCustomerReference(dbReference), nil
+}
+
+// GetCustomerReference retrieves the customer a tenant's external reference maps to
+func (r *Repository) GetCustomerReference(ctx context.Context, tenantID, externalReference string) (*customers.Reference, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetCustomerReference")
+	defer span.End()
+
+	dbReference, err := r.queries.GetCustomerReference(ctx, r.db, sqlc.GetCustomerReferenceParams{
+		TenantID:          tenantID,
+		ExternalReference: externalReference,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer reference: %w", err)
+	}
+
+	return convertCustomerReference(dbReference), nil
+}
+
+// DeleteCustomerReferences removes a customer's external references
+func (r *Repository) DeleteCustomerReferences(ctx context.Context, customerID string) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.DeleteCustomerReferences")
+	defer span.End()
+
+	if err := r.queries.DeleteCustomerReferences(ctx, r.db, customerID); err != nil {
+		return fmt.Errorf("failed to delete customer references: %w", err)
+	}
+
+	return nil
+}
+
+// convertCustomerIdentity converts a database customer identity to a service identity
+func convertCustomerIdentity(dbIdentity sqlc.CustomerIdentity) *customers.Identity {
+	identity := &customers.Identity{
+		CustomerID:            dbIdentity.CustomerID,
+		TenantID:              dbIdentity.TenantID,
+		Email:                 dbIdentity.NormalizedEmail,
+		Name:                  dbIdentity.Name,
+		EmailVerified:         dbIdentity.EmailVerifiedAt.Valid,
+		VerificationTokenHash: dbIdentity.VerificationTokenHash,
+		CreatedAt:             dbIdentity.CreatedAt.Time,
+	}
+	if dbIdentity.EmailVerifiedAt.Valid {
+		verifiedAt := dbIdentity.EmailVerifiedAt.Time
+		identity.EmailVerifiedAt = &verifiedAt
+	}
+	if dbIdentity.VerificationExpiresAt.Valid {
+		expiresAt := dbIdentity.VerificationExpiresAt.Time
+		identity.VerificationExpiresAt = &expiresAt
+	}
+
+	return identity
+}
+
+// convertCustomerReference converts a database customer reference
+func convertCustomerReference(dbReference sqlc.CustomerReference) *customers.Reference {
+	return &customers.Reference{
+		TenantID:          dbReference.TenantID,
+		ExternalReference: dbReference.ExternalReference,
+		CustomerID:        dbReference.CustomerID,
+		CreatedAt:         dbReference.CreatedAt.Time,
+	}
+}
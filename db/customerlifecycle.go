@@ -0,0 +1,183 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/customers"
+)
+
+// CreateCustomerDeletion records a customer's deletion, returning the
+// existing deletion if it is already deleted
+func (r *Repository) CreateCustomerDeletion(ctx context.Context, deletion *customers.Deletion) (*customers.Deletion, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.CreateCustomerDeletion")
+	defer span.End()
+
+	params := sqlc.CreateCustomerDeletionParams{
+		CustomerID: deletion.CustomerID,
+		TenantID:   deletion.TenantID,
+		DeletedAt:  deletion.DeletedAt,
+		PurgeAfter: deletion.PurgeAfter,
+	}
+
+	dbDeletion, err := r.queries.CreateCustomerDeletion(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create customer deletion: %w", err)
+	}
+
+	return convertCustomerDeletion(dbDeletion), nil
+}
+
+// GetCustomerDeletion retrieves a customer's deletion
+func (r *Repository) GetCustomerDeletion(ctx context.Context, customerID string) (*customers.Deletion, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetCustomerDeletion")
+	defer span.End()
+
+	dbDeletion, err := r.queries.GetCustomerDeletion(ctx, r.db, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer deletion: %w", err)
+	}
+
+	return convertCustomerDeletion(dbDeletion), nil
+}
+
+// DeleteCustomerDeletion removes a deletion not yet purged, reporting
+// whether there was one
+func (r *Repository) DeleteCustomerDeletion(ctx context.Context, customerID string) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.DeleteCustomerDeletion")
+	defer span.End()
+
+	rows, err := r.queries.DeleteCustomerDeletion(ctx, r.db, customerID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete customer deletion: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// ListDueCustomerDeletions retrieves deletions past their retention period
+// and not yet purged, the longest due first
+func (r *Repository) ListDueCustomerDeletions(ctx context.Context, now time.Time, limit int) ([]*customers.Deletion, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListDueCustomerDeletions")
+	defer span.End()
+
+	params := sqlc.ListDueCustomerDeletionsParams{PurgeAfter: now, Limit: int32(limit)}
+	dbDeletions, err := r.queries.ListDueCustomerDeletions(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due customer deletions: %w", err)
+	}
+
+	deletions := make([]*customers.Deletion, len(dbDeletions))
+	for i, dbDeletion := range dbDeletions {
+		deletions[i] = convertCustomerDeletion(dbDeletion)
+	}
+
+	return deletions, nil
+}
+
+// ClaimCustomerDeletion marks a deletion purged unless it already is,
+// returning sql.ErrNoRows if so
+func (r *Repository) ClaimCustomerDeletion(ctx context.Context, customerID string, purgedAt time.Time) (*customers.Deletion, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ClaimCustomerDeletion")
+	defer span.End()
+
+	params := sqlc.ClaimCustomerDeletionParams{
+		CustomerID: customerID,
+		PurgedAt:   sql.NullTime{Time: purgedAt, Valid: true},
+	}
+
+	dbDeletion, err := r.queries.ClaimCustomerDeletion(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim customer deletion: %w", err)
+	}
+
+	return convertCustomerDeletion(dbDeletion), nil
+}
+
+// ReleaseCustomerDeletion marks a claimed deletion not purged after all
+func (r *Repository) ReleaseCustomerDeletion(ctx context.Context, customerID string) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.ReleaseCustomerDeletion")
+	defer span.End()
+
+	if err := r.queries.ReleaseCustomerDeletion(ctx, r.db, customerID); err != nil {
+		return fmt.Errorf("failed to release customer deletion: %w", err)
+	}
+
+	return nil
+}
+
+// EraseCustomer records a customer's erasure, then blanks its email, name
+// and phone on the mirrored customer and its charge list rows and removes
+// its identity and mirrored payment methods. Charges and refunds are kept.
+func (r *Repository) EraseCustomer(ctx context.Context, erasure *customers.Erasure) (*customers.Erasure, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.EraseCustomer")
+	defer span.End()
+
+	dbErasure, err := r.queries.RecordCustomerErasure(ctx, r.db, sqlc.RecordCustomerErasureParams{
+		CustomerID: erasure.CustomerID,
+		TenantID:   erasure.TenantID,
+		ErasedAt:   erasure.ErasedAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record customer erasure: %w", err)
+	}
+
+	params := sqlc.AnonymizeCustomerParams{
+		ID:       erasure.CustomerID,
+		TenantID: erasure.TenantID,
+		SyncedAt: erasure.ErasedAt,
+	}
+	if err := r.queries.AnonymizeCustomer(ctx, r.db, params); err != nil {
+		return nil, fmt.Errorf("failed to anonymize customer: %w", err)
+	}
+	if err := r.queries.AnonymizeChargeListRows(ctx, r.db, erasure.CustomerID); err != nil {
+		return nil, fmt.Errorf("failed to anonymize charge list rows: %w", err)
+	}
+	if err := r.queries.DeleteCustomerIdentity(ctx, r.db, erasure.CustomerID); err != nil {
+		return nil, fmt.Errorf("failed to delete customer identity: %w", err)
+	}
+	if err := r.queries.DeleteCustomerPaymentMethods(ctx, r.db, erasure.CustomerID); err != nil {
+		return nil, fmt.Errorf("failed to delete customer payment methods: %w", err)
+	}
+
+	return convertCustomerErasure(dbErasure), nil
+}
+
+// GetCustomerErasure retrieves a customer's erasure
+func (r *Repository) GetCustomerErasure(ctx context.Context, customerID string) (*customers.Erasure, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetCustomerErasure")
+	defer span.End()
+
+	dbErasure, err := r.queries.GetCustomerErasure(ctx, r.db, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer erasure: %w", err)
+	}
+
+	return convertCustomerErasure(dbErasure), nil
+}
+
+// convertCustomerDeletion converts a database customer deletion to a service deletion
+func convertCustomerDeletion(dbDeletion sqlc.CustomerDeletion) *customers.Deletion {
+	deletion := &customers.Deletion{
+		CustomerID: dbDeletion.CustomerID,
+		TenantID:   dbDeletion.TenantID,
+		DeletedAt:  dbDeletion.DeletedAt,
+		PurgeAfter: dbDeletion.PurgeAfter,
+	}
+	if dbDeletion.PurgedAt.Valid {
+		deletion.PurgedAt = &dbDeletion.PurgedAt.Time
+	}
+	return deletion
+}
+
+// convertCustomerErasure converts a database customer erasure to a service erasure
+func convertCustomerErasure(dbErasure sqlc.CustomerErasure) *customers.Erasure {
+	return &customers.Erasure{
+		CustomerID: dbErasure.CustomerID,
+		TenantID:   dbErasure.TenantID,
+		ErasedAt:   dbErasure.ErasedAt,
+	}
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/customers"
+	"apis/payments/services/stripe"
+)
+
+// ListCustomers lists a tenant's mirrored customers matching a filter,
+// newest first
+func (r *Repository) ListCustomers(ctx context.Context, filter customers.Filter) ([]*stripe.Customer, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListCustomers")
+	defer span.End()
+
+	metadata, err := json.Marshal(filter.Metadata)
+	if err != nil || len(filter.Metadata) == 0 {
+		metadata = []byte("{}")
+	}
+
+	params := sqlc.ListTenantCustomersParams{
+		TenantID:      filter.TenantID,
+		Email:         filter.Email,
+		NamePrefix:    filter.NamePrefix,
+		Metadata:      metadata,
+		StartingAfter: filter.StartingAfter,
+		Limit:         int32(filter.Limit),
+	}
+	if filter.CreatedAfter != nil {
+		params.CreatedAfter = sql.NullTime{Time: *filter.CreatedAfter, Valid: true}
+	}
+	if filter.CreatedBefore != nil {
+		params.CreatedBefore = sql.NullTime{Time: *filter.CreatedBefore, Valid: true}
+	}
+
+	dbCustomers, err := r.queries.ListTenantCustomers(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list customers: %w", err)
+	}
+
+	return convertMirroredCustomers(dbCustomers), nil
+}
+
+// SearchCustomers finds a tenant's mirrored customers by ID, or by the start
+// of their email or name
+func (r *Repository) SearchCustomers(ctx context.Context, tenantID, customerID, prefix string, limit int) ([]*stripe.Customer, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.SearchCustomers")
+	defer span.End()
+
+	dbCustomers, err := r.queries.SearchTenantCustomers(ctx, r.db, sqlc.SearchTenantCustomersParams{
+		TenantID: tenantID,
+		Query:    customerID,
+		Prefix:   prefix,
+		Limit:    int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search customers: %w", err)
+	}
+
+	return convertMirroredCustomers(dbCustomers), nil
+}
+
+// convertMirroredCustomers converts mirrored database customers to service customers
+func convertMirroredCustomers(dbCustomers []sqlc.Customer) []*stripe.Customer {
+	result := make([]*stripe.Customer, len(dbCustomers))
+	for i, dbCustomer := range dbCustomers {
+		result[i] = &stripe.Customer{
+			ID:          dbCustomer.ID,
+			Email:       dbCustomer.Email,
+			Name:        dbCustomer.Name,
+			Phone:       dbCustomer.Phone.String,
+			Description: dbCustomer.Description.String,
+			Metadata:    decodeMetadata(dbCustomer.Metadata),
+			Created:     dbCustomer.CreatedAt.Time.Unix(),
+			Updated:     dbCustomer.UpdatedAt.Time.Unix(),
+		}
+	}
+	return result
+}
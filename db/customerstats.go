@@ -0,0 +1,56 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/customerstats"
+)
+
+// GetCustomerChargeStats aggregates a customer's charges created since from,
+// per currency
+func (r *Repository) GetCustomerChargeStats(ctx context.Context, customerID string, from time.Time) ([]*customerstats.CurrencyStats, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetCustomerChargeStats")
+	defer span.End()
+
+	dbRows, err := r.queries.GetCustomerChargeStats(ctx, r.db, sqlc.GetCustomerChargeStatsParams{
+		CustomerID:  customerID,
+		CreatedFrom: sql.NullTime{Time: from, Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer charge stats: %w", err)
+	}
+
+	stats := make([]*customerstats.CurrencyStats, len(dbRows))
+	for i, dbRow := range dbRows {
+		stats[i] = &customerstats.CurrencyStats{
+			Currency:       dbRow.Currency,
+			ChargeCount:    dbRow.ChargeCount,
+			SucceededCount: dbRow.SucceededCount,
+			RefundedCount:  dbRow.RefundedCount,
+			DisputeCount:   dbRow.DisputeCount,
+			GrossAmount:    dbRow.SucceededAmount,
+			RefundedAmount: dbRow.RefundedAmount,
+			FirstChargeAt:  dbRow.FirstChargeAt,
+			LastChargeAt:   dbRow.LastChargeAt,
+		}
+	}
+
+	return stats, nil
+}
+
+// GetChargeCustomer retrieves the customer of a locally stored charge
+func (r *Repository) GetChargeCustomer(ctx context.Context, chargeID string) (string, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetChargeCustomer")
+	defer span.End()
+
+	customerID, err := r.queries.GetChargeCustomer(ctx, r.db, chargeID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get charge customer: %w", err)
+	}
+
+	return customerID, nil
+}
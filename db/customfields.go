@@ -0,0 +1,74 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/customfields"
+)
+
+// GetCustomFieldDefinition retrieves the custom fields a tenant has defined
+func (r *Repository) GetCustomFieldDefinition(ctx context.Context, tenantID string) (*customfields.Definition, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetCustomFieldDefinition")
+	defer span.End()
+
+	dbDefinition, err := r.queries.GetCustomFieldDefinition(ctx, r.db, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get custom field definition: %w", err)
+	}
+
+	return convertCustomFieldDefinition(dbDefinition)
+}
+
+// UpsertCustomFieldDefinition creates or replaces a tenant's custom fields
+func (r *Repository) UpsertCustomFieldDefinition(ctx context.Context, definition *customfields.Definition) (*customfields.Definition, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.UpsertCustomFieldDefinition")
+	defer span.End()
+
+	raw, err := json.Marshal(definition.Fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal custom fields: %w", err)
+	}
+
+	params := sqlc.UpsertCustomFieldDefinitionParams{
+		TenantID: definition.TenantID,
+		Fields:   raw,
+	}
+
+	dbDefinition, err := r.queries.UpsertCustomFieldDefinition(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert custom field definition: %w", err)
+	}
+
+	return convertCustomFieldDefinition(dbDefinition)
+}
+
+// DeleteCustomFieldDefinition removes a tenant's custom fields, reporting whether any existed
+func (r *Repository) DeleteCustomFieldDefinition(ctx context.Context, tenantID string) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.DeleteCustomFieldDefinition")
+	defer span.End()
+
+	rows, err := r.queries.DeleteCustomFieldDefinition(ctx, r.db, tenantID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete custom field definition: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// convertCustomFieldDefinition converts a database custom field definition
+func convertCustomFieldDefinition(dbDefinition sqlc.CustomFieldDefinition) (*customfields.Definition, error) {
+	var fields []*customfields.Field
+	if err := json.Unmarshal(dbDefinition.Fields, &fields); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal custom fields: %w", err)
+	}
+
+	return &customfields.Definition{
+		TenantID:  dbDefinition.TenantID,
+		Fields:    fields,
+		CreatedAt: dbDefinition.CreatedAt.Time,
+		UpdatedAt: dbDefinition.UpdatedAt.Time,
+	}, nil
+}
@@ -0,0 +1,189 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/deadletter"
+)
+
+// CreateDeadLetter stores a failed event, or updates the error of the entry
+// already stored for the same source and key
+func (r *Repository) CreateDeadLetter(ctx context.Context, entry *deadletter.Entry) (*deadletter.Entry, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.CreateDeadLetter")
+	defer span.End()
+
+	headers, err := json.Marshal(entry.Headers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal dead letter headers: %w", err)
+	}
+
+	params := sqlc.CreateDeadLetterParams{
+		ID:        entry.ID,
+		Source:    entry.Source,
+		DedupeKey: entry.Key,
+		Payload:   entry.Payload,
+		Headers:   headers,
+		LastError: entry.LastError,
+	}
+	if entry.NextAttemptAt != nil {
+		params.NextAttemptAt = sql.NullTime{Time: *entry.NextAttemptAt, Valid: true}
+	}
+
+	dbEntry, err := r.queries.CreateDeadLetter(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dead letter: %w", err)
+	}
+
+	return convertDeadLetter(dbEntry)
+}
+
+// GetDeadLetter retrieves a dead-letter entry by ID
+func (r *Repository) GetDeadLetter(ctx context.Context, id string) (*deadletter.Entry, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetDeadLetter")
+	defer span.End()
+
+	dbEntry, err := r.queries.GetDeadLetter(ctx, r.db, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dead letter: %w", err)
+	}
+
+	return convertDeadLetter(dbEntry)
+}
+
+// ListDeadLetters retrieves dead-letter entries, newest first
+func (r *Repository) ListDeadLetters(ctx context.Context, filter deadletter.Filter) ([]*deadletter.Entry, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListDeadLetters")
+	defer span.End()
+
+	dbEntries, err := r.queries.ListDeadLetters(ctx, r.db, sqlc.ListDeadLettersParams{
+		Status: filter.Status,
+		Source: filter.Source,
+		Limit:  int32(filter.Limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+
+	return convertDeadLetters(dbEntries)
+}
+
+// ClaimDueDeadLetters leases pending entries whose next attempt is due,
+// skipping entries other workers hold
+func (r *Repository) ClaimDueDeadLetters(ctx context.Context, leaseUntil time.Time, limit int) ([]*deadletter.Entry, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ClaimDueDeadLetters")
+	defer span.End()
+
+	dbEntries, err := r.queries.ClaimDueDeadLetters(ctx, r.db, sqlc.ClaimDueDeadLettersParams{
+		NextAttemptAt: sql.NullTime{Time: leaseUntil, Valid: true},
+		Limit:         int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim dead letters: %w", err)
+	}
+
+	return convertDeadLetters(dbEntries)
+}
+
+// UpdateDeadLetter records the outcome of a retry
+func (r *Repository) UpdateDeadLetter(ctx context.Context, entry *deadletter.Entry) (*deadletter.Entry, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.UpdateDeadLetter")
+	defer span.End()
+
+	params := sqlc.UpdateDeadLetterParams{
+		ID:        entry.ID,
+		Status:    entry.Status,
+		Attempts:  int32(entry.Attempts),
+		LastError: entry.LastError,
+	}
+	if entry.NextAttemptAt != nil {
+		params.NextAttemptAt = sql.NullTime{Time: *entry.NextAttemptAt, Valid: true}
+	}
+	if entry.ResolvedAt != nil {
+		params.ResolvedAt = sql.NullTime{Time: *entry.ResolvedAt, Valid: true}
+	}
+
+	dbEntry, err := r.queries.UpdateDeadLetter(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update dead letter: %w", err)
+	}
+
+	return convertDeadLetter(dbEntry)
+}
+
+// DeleteDeadLetter removes a dead-letter entry, reporting whether it existed
+func (r *Repository) DeleteDeadLetter(ctx context.Context, id string) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.DeleteDeadLetter")
+	defer span.End()
+
+	rows, err := r.queries.DeleteDeadLetter(ctx, r.db, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete dead letter: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// PurgeDeadLetters removes entries in a status created before a time
+func (r *Repository) PurgeDeadLetters(ctx context.Context, status string, before time.Time) (int64, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.PurgeDeadLetters")
+	defer span.End()
+
+	rows, err := r.queries.PurgeDeadLetters(ctx, r.db, sqlc.PurgeDeadLettersParams{
+		Status:    status,
+		CreatedAt: sql.NullTime{Time: before, Valid: true},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge dead letters: %w", err)
+	}
+
+	return rows, nil
+}
+
+// convertDeadLetters converts database dead-letter entries
+func convertDeadLetters(dbEntries []sqlc.DlqEvent) ([]*deadletter.Entry, error) {
+	entries := make([]*deadletter.Entry, len(dbEntries))
+	for i, dbEntry := range dbEntries {
+		entry, err := convertDeadLetter(dbEntry)
+		if err != nil {
+			return nil, err
+		}
+		entries[i] = entry
+	}
+	return entries, nil
+}
+
+// convertDeadLetter converts a database dead-letter entry
+func convertDeadLetter(dbEntry sqlc.DlqEvent) (*deadletter.Entry, error) {
+	var headers map[string]string
+	if len(dbEntry.Headers) > 0 {
+		if err := json.Unmarshal(dbEntry.Headers, &headers); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal dead letter headers: %w", err)
+		}
+	}
+
+	entry := &deadletter.Entry{
+		ID:        dbEntry.ID,
+		Source:    dbEntry.Source,
+		Key:       dbEntry.DedupeKey,
+		Payload:   dbEntry.Payload,
+		Headers:   headers,
+		Status:    dbEntry.Status,
+		Attempts:  int(dbEntry.Attempts),
+		LastError: dbEntry.LastError,
+		CreatedAt: dbEntry.CreatedAt.Time,
+		UpdatedAt: dbEntry.UpdatedAt.Time,
+	}
+	if dbEntry.NextAttemptAt.Valid {
+		entry.NextAttemptAt = &dbEntry.NextAttemptAt.Time
+	}
+	if dbEntry.ResolvedAt.Valid {
+		entry.ResolvedAt = &dbEntry.ResolvedAt.Time
+	}
+
+	return entry, nil
+}
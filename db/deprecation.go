@@ -0,0 +1,53 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/deprecation"
+)
+
+// RecordDeprecatedUsage adds usage of a deprecated surface to its running total
+func (r *Repository) RecordDeprecatedUsage(ctx context.Context, usage *deprecation.Usage) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.RecordDeprecatedUsage")
+	defer span.End()
+
+	params := sqlc.RecordDeprecatedUsageParams{
+		NoticeID:     usage.NoticeID,
+		ApiKeyID:     usage.APIKeyID,
+		RequestCount: usage.Count,
+		FirstSeen:    usage.FirstSeen,
+		LastSeen:     usage.LastSeen,
+	}
+
+	if err := r.queries.RecordDeprecatedUsage(ctx, r.db, params); err != nil {
+		return fmt.Errorf("failed to record deprecated usage: %w", err)
+	}
+
+	return nil
+}
+
+// ListDeprecatedUsage retrieves usage of every deprecated surface
+func (r *Repository) ListDeprecatedUsage(ctx context.Context) ([]*deprecation.Usage, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListDeprecatedUsage")
+	defer span.End()
+
+	dbUsages, err := r.queries.ListDeprecatedUsage(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deprecated usage: %w", err)
+	}
+
+	usages := make([]*deprecation.Usage, len(dbUsages))
+	for i, dbUsage := range dbUsages {
+		usages[i] = &deprecation.Usage{
+			NoticeID:  dbUsage.NoticeID,
+			APIKeyID:  dbUsage.ApiKeyID,
+			Count:     dbUsage.RequestCount,
+			FirstSeen: dbUsage.FirstSeen,
+			LastSeen:  dbUsage.LastSeen,
+		}
+	}
+
+	return usages, nil
+}
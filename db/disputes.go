@@ -0,0 +1,315 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/disputes"
+	"apis/payments/services/money"
+	"apis/payments/services/stripe"
+)
+
+// UpsertDispute stores a dispute unless a newer version is already stored
+func (r *Repository) UpsertDispute(ctx context.Context, dispute *stripe.Dispute, syncedAt time.Time) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.UpsertDispute")
+	defer span.End()
+
+	evidence, err := json.Marshal(dispute.Evidence)
+	if err != nil {
+		return fmt.Errorf("failed to marshal dispute evidence: %w", err)
+	}
+	balanceTransactions, err := json.Marshal(dispute.BalanceTransactions)
+	if err != nil {
+		return fmt.Errorf("failed to marshal dispute balance transactions: %w", err)
+	}
+	metadata, err := json.Marshal(dispute.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal dispute metadata: %w", err)
+	}
+
+	params := sqlc.UpsertDisputeParams{
+		ID:                  dispute.ID,
+		ChargeID:            dispute.ChargeID,
+		PaymentIntentID:     dispute.PaymentIntentID,
+		Amount:              dispute.Amount,
+		Currency:            dispute.Currency,
+		Reason:              dispute.Reason,
+		Status:              dispute.Status,
+		NetworkReasonCode:   dispute.NetworkReasonCode,
+		IsChargeRefundable:  dispute.IsChargeRefundable,
+		Evidence:            evidence,
+		BalanceTransactions: balanceTransactions,
+		Livemode:            dispute.Livemode,
+		Metadata:            metadata,
+		DisputedAt:          time.Unix(dispute.Created, 0).UTC(),
+		SyncedAt:            syncedAt,
+		TenantID:            scopedTenant(ctx),
+	}
+	if details := dispute.EvidenceDetails; details != nil {
+		params.HasEvidence = details.HasEvidence
+		params.PastDue = details.PastDue
+		params.SubmissionCount = int32(details.SubmissionCount)
+		if details.DueBy != nil {
+			params.EvidenceDueBy = sql.NullTime{Time: *details.DueBy, Valid: true}
+		}
+	}
+	if details := dispute.PaymentMethodDetails; details != nil {
+		params.PaymentMethodType = details.Type
+		params.CardBrand = details.CardBrand
+		params.CardNetworkReasonCode = details.CardNetworkReasonCode
+	}
+
+	if err := r.queries.UpsertDispute(ctx, r.db, params); err != nil {
+		return fmt.Errorf("failed to upsert dispute: %w", err)
+	}
+
+	return nil
+}
+
+// GetDispute retrieves a dispute by ID
+func (r *Repository) GetDispute(ctx context.Context, disputeID string) (*stripe.Dispute, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetDispute")
+	defer span.End()
+
+	dbDispute, err := r.queries.GetDispute(ctx, r.db, sqlc.GetDisputeParams{
+		ID:       disputeID,
+		TenantID: scopedTenant(ctx),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dispute: %w", err)
+	}
+
+	return convertDispute(dbDispute), nil
+}
+
+// ListDisputes retrieves disputes matching a filter, newest first
+func (r *Repository) ListDisputes(ctx context.Context, filter disputes.Filter) ([]*stripe.Dispute, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListDisputes")
+	defer span.End()
+
+	params := sqlc.ListDisputesParams{
+		ChargeID: filter.ChargeID,
+		Status:   filter.Status,
+		Limit:    int32(filter.Limit),
+		TenantID: scopedTenant(ctx),
+	}
+
+	dbDisputes, err := r.queries.ListDisputes(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list disputes: %w", err)
+	}
+
+	result := make([]*stripe.Dispute, len(dbDisputes))
+	for i, dbDispute := range dbDisputes {
+		result[i] = convertDispute(dbDispute)
+	}
+
+	return result, nil
+}
+
+// convertDispute converts a database dispute to a service dispute
+func convertDispute(dbDispute sqlc.Dispute) *stripe.Dispute {
+	dispute := &stripe.Dispute{
+		ID:                 dbDispute.ID,
+		ChargeID:           dbDispute.ChargeID,
+		PaymentIntentID:    dbDispute.PaymentIntentID,
+		Amount:             dbDispute.Amount,
+		AmountDisplay:      money.Describe(dbDispute.Amount, dbDispute.Currency),
+		Currency:           dbDispute.Currency,
+		Reason:             dbDispute.Reason,
+		Status:             dbDispute.Status,
+		NetworkReasonCode:  dbDispute.NetworkReasonCode,
+		IsChargeRefundable: dbDispute.IsChargeRefundable,
+		Evidence:           &stripe.DisputeEvidence{},
+		EvidenceDetails: &stripe.DisputeEvidenceDetails{
+			HasEvidence:     dbDispute.HasEvidence,
+			PastDue:         dbDispute.PastDue,
+			SubmissionCount: int64(dbDispute.SubmissionCount),
+		},
+		Livemode: dbDispute.Livemode,
+		Created:  dbDispute.DisputedAt.Unix(),
+	}
+
+	if dbDispute.EvidenceDueBy.Valid {
+		dueBy := dbDispute.EvidenceDueBy.Time
+		dispute.EvidenceDetails.DueBy = &dueBy
+	}
+	if dbDispute.PaymentMethodType != "" {
+		dispute.PaymentMethodDetails = &stripe.DisputePaymentMethodDetails{
+			Type:                  dbDispute.PaymentMethodType,
+			CardBrand:             dbDispute.CardBrand,
+			CardNetworkReasonCode: dbDispute.CardNetworkReasonCode,
+		}
+	}
+
+	_ = json.Unmarshal(dbDispute.Evidence, dispute.Evidence)
+	_ = json.Unmarshal(dbDispute.BalanceTransactions, &dispute.BalanceTransactions)
+	_ = json.Unmarshal(dbDispute.Metadata, &dispute.Metadata)
+
+	return dispute
+}
+
+// GetDisputeEvidence retrieves the evidence staged for a dispute
+func (r *Repository) GetDisputeEvidence(ctx context.Context, disputeID string) (*disputes.Evidence, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetDisputeEvidence")
+	defer span.End()
+
+	dbEvidence, err := r.queries.GetDisputeEvidence(ctx, r.db, disputeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dispute evidence: %w", err)
+	}
+
+	return convertDisputeEvidence(dbEvidence), nil
+}
+
+// SaveDisputeEvidence replaces the evidence fields staged for a dispute
+func (r *Repository) SaveDisputeEvidence(ctx context.Context, disputeID string, fields map[string]string, updatedBy string) (*disputes.Evidence, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.SaveDisputeEvidence")
+	defer span.End()
+
+	fieldsJSON, err := json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal evidence fields: %w", err)
+	}
+
+	dbEvidence, err := r.queries.SaveDisputeEvidence(ctx, r.db, sqlc.SaveDisputeEvidenceParams{
+		DisputeID: disputeID,
+		Fields:    fieldsJSON,
+		UpdatedBy: updatedBy,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save dispute evidence: %w", err)
+	}
+
+	return convertDisputeEvidence(dbEvidence), nil
+}
+
+// MarkDisputeEvidenceSubmitted records who submitted a dispute's evidence
+func (r *Repository) MarkDisputeEvidenceSubmitted(ctx context.Context, disputeID, submittedBy string) (*disputes.Evidence, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.MarkDisputeEvidenceSubmitted")
+	defer span.End()
+
+	dbEvidence, err := r.queries.MarkDisputeEvidenceSubmitted(ctx, r.db, sqlc.MarkDisputeEvidenceSubmittedParams{
+		DisputeID:   disputeID,
+		SubmittedBy: submittedBy,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark dispute evidence submitted: %w", err)
+	}
+
+	return convertDisputeEvidence(dbEvidence), nil
+}
+
+// CreateDisputeEvidenceFile records a file uploaded as dispute evidence
+func (r *Repository) CreateDisputeEvidenceFile(ctx context.Context, file *disputes.EvidenceFile) (*disputes.EvidenceFile, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.CreateDisputeEvidenceFile")
+	defer span.End()
+
+	dbFile, err := r.queries.CreateDisputeEvidenceFile(ctx, r.db, sqlc.CreateDisputeEvidenceFileParams{
+		ID:             file.ID,
+		DisputeID:      file.DisputeID,
+		ProviderFileID: file.ProviderFileID,
+		Field:          file.Field,
+		Filename:       file.Filename,
+		ContentType:    file.ContentType,
+		Size:           file.Size,
+		UploadedBy:     file.UploadedBy,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dispute evidence file: %w", err)
+	}
+
+	return convertDisputeEvidenceFile(dbFile), nil
+}
+
+// ListDisputeEvidenceFiles retrieves the files uploaded for a dispute, oldest first
+func (r *Repository) ListDisputeEvidenceFiles(ctx context.Context, disputeID string) ([]*disputes.EvidenceFile, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListDisputeEvidenceFiles")
+	defer span.End()
+
+	dbFiles, err := r.queries.ListDisputeEvidenceFiles(ctx, r.db, disputeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dispute evidence files: %w", err)
+	}
+
+	files := make([]*disputes.EvidenceFile, len(dbFiles))
+	for i, dbFile := range dbFiles {
+		files[i] = convertDisputeEvidenceFile(dbFile)
+	}
+
+	return files, nil
+}
+
+// ListDisputesDueForEvidence retrieves disputes still needing a response whose
+// evidence deadline falls in a window, soonest first
+func (r *Repository) ListDisputesDueForEvidence(ctx context.Context, dueAfter, dueBefore time.Time) ([]*stripe.Dispute, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListDisputesDueForEvidence")
+	defer span.End()
+
+	dbDisputes, err := r.queries.ListDisputesDueForEvidence(ctx, r.db, sqlc.ListDisputesDueForEvidenceParams{
+		DueAfter:  sql.NullTime{Time: dueAfter, Valid: true},
+		DueBefore: sql.NullTime{Time: dueBefore, Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list disputes due for evidence: %w", err)
+	}
+
+	result := make([]*stripe.Dispute, len(dbDisputes))
+	for i, dbDispute := range dbDisputes {
+		result[i] = convertDispute(dbDispute)
+	}
+
+	return result, nil
+}
+
+// ClaimDisputeEvidenceReminder records a reminder for a dispute's evidence
+// deadline, reporting false if one was already sent
+func (r *Repository) ClaimDisputeEvidenceReminder(ctx context.Context, disputeID string, dueBy time.Time) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ClaimDisputeEvidenceReminder")
+	defer span.End()
+
+	rows, err := r.queries.ClaimDisputeEvidenceReminder(ctx, r.db, sqlc.ClaimDisputeEvidenceReminderParams{
+		DisputeID: disputeID,
+		DueBy:     dueBy,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to claim dispute evidence reminder: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// convertDisputeEvidence converts database dispute evidence to service evidence
+func convertDisputeEvidence(dbEvidence sqlc.DisputeEvidence) *disputes.Evidence {
+	evidence := &disputes.Evidence{
+		DisputeID:   dbEvidence.DisputeID,
+		UpdatedBy:   dbEvidence.UpdatedBy,
+		SubmittedBy: dbEvidence.SubmittedBy,
+		UpdatedAt:   dbEvidence.UpdatedAt.Time,
+	}
+	if dbEvidence.SubmittedAt.Valid {
+		submittedAt := dbEvidence.SubmittedAt.Time
+		evidence.SubmittedAt = &submittedAt
+	}
+	_ = json.Unmarshal(dbEvidence.Fields, &evidence.Fields)
+	return evidence
+}
+
+// convertDisputeEvidenceFile converts a database evidence file to a service file
+func convertDisputeEvidenceFile(dbFile sqlc.DisputeEvidenceFile) *disputes.EvidenceFile {
+	return &disputes.EvidenceFile{
+		ID:             dbFile.ID,
+		DisputeID:      dbFile.DisputeID,
+		ProviderFileID: dbFile.ProviderFileID,
+		Field:          dbFile.Field,
+		Filename:       dbFile.Filename,
+		ContentType:    dbFile.ContentType,
+		Size:           dbFile.Size,
+		UploadedBy:     dbFile.UploadedBy,
+		CreatedAt:      dbFile.CreatedAt.Time,
+	}
+}
@@ -0,0 +1,155 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/documents"
+)
+
+// GetDocumentTemplate retrieves a tenant's template for a kind of document
+func (r *Repository) GetDocumentTemplate(ctx context.Context, tenantID, kind string) (*documents.Template, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetDocumentTemplate")
+	defer span.End()
+
+	dbTemplate, err := r.queries.GetDocumentTemplate(ctx, r.db, sqlc.GetDocumentTemplateParams{
+		TenantID: tenantID,
+		Kind:     kind,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document template: %w", err)
+	}
+
+	return convertDocumentTemplate(dbTemplate), nil
+}
+
+// UpsertDocumentTemplate creates or replaces a tenant's template for a kind
+// of document
+func (r *Repository) UpsertDocumentTemplate(ctx context.Context, template *documents.Template) (*documents.Template, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.UpsertDocumentTemplate")
+	defer span.End()
+
+	dbTemplate, err := r.queries.UpsertDocumentTemplate(ctx, r.db, sqlc.UpsertDocumentTemplateParams{
+		TenantID:     template.TenantID,
+		Kind:         template.Kind,
+		BusinessName: template.BusinessName,
+		Address:      template.Address,
+		TaxID:        template.TaxID,
+		AccentColor:  template.AccentColor,
+		Footer:       template.Footer,
+		Language:     template.Language,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert document template: %w", err)
+	}
+
+	return convertDocumentTemplate(dbTemplate), nil
+}
+
+// DeleteDocumentTemplate removes a tenant's template for a kind of
+// document, reporting whether it existed
+func (r *Repository) DeleteDocumentTemplate(ctx context.Context, tenantID, kind string) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.DeleteDocumentTemplate")
+	defer span.End()
+
+	rows, err := r.queries.DeleteDocumentTemplate(ctx, r.db, sqlc.DeleteDocumentTemplateParams{
+		TenantID: tenantID,
+		Kind:     kind,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to delete document template: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// GetDocument retrieves a tenant's document by ID
+func (r *Repository) GetDocument(ctx context.Context, tenantID, id string) (*documents.Document, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetDocument")
+	defer span.End()
+
+	dbDocument, err := r.queries.GetDocument(ctx, r.db, sqlc.GetDocumentParams{
+		ID:       id,
+		TenantID: tenantID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document: %w", err)
+	}
+
+	return convertDocument(dbDocument), nil
+}
+
+// GetDocumentBySource retrieves the document rendered from an invoice or
+// charge in a language
+func (r *Repository) GetDocumentBySource(ctx context.Context, tenantID, kind, sourceID, language string) (*documents.Document, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetDocumentBySource")
+	defer span.End()
+
+	dbDocument, err := r.queries.GetDocumentBySource(ctx, r.db, sqlc.GetDocumentBySourceParams{
+		TenantID: tenantID,
+		Kind:     kind,
+		SourceID: sourceID,
+		Language: language,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document: %w", err)
+	}
+
+	return convertDocument(dbDocument), nil
+}
+
+// UpsertDocument records a rendered document, replacing the earlier
+// rendering of the same source and language
+func (r *Repository) UpsertDocument(ctx context.Context, document *documents.Document) (*documents.Document, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.UpsertDocument")
+	defer span.End()
+
+	dbDocument, err := r.queries.UpsertDocument(ctx, r.db, sqlc.UpsertDocumentParams{
+		ID:        document.ID,
+		TenantID:  document.TenantID,
+		Kind:      document.Kind,
+		SourceID:  document.SourceID,
+		Language:  document.Language,
+		ObjectKey: document.ObjectKey,
+		Checksum:  document.Checksum,
+		SizeBytes: document.Size,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert document: %w", err)
+	}
+
+	return convertDocument(dbDocument), nil
+}
+
+// convertDocumentTemplate converts a database document template
+func convertDocumentTemplate(dbTemplate sqlc.DocumentTemplate) *documents.Template {
+	return &documents.Template{
+		TenantID:     dbTemplate.TenantID,
+		Kind:         dbTemplate.Kind,
+		BusinessName: dbTemplate.BusinessName,
+		Address:      dbTemplate.Address,
+		TaxID:        dbTemplate.TaxID,
+		AccentColor:  dbTemplate.AccentColor,
+		Footer:       dbTemplate.Footer,
+		Language:     dbTemplate.Language,
+		CreatedAt:    dbTemplate.CreatedAt.Time,
+		UpdatedAt:    dbTemplate.UpdatedAt.Time,
+	}
+}
+
+// convertDocument converts a database document
+func convertDocument(dbDocument sqlc.Document) *documents.Document {
+	return &documents.Document{
+		ID:        dbDocument.ID,
+		TenantID:  dbDocument.TenantID,
+		Kind:      dbDocument.Kind,
+		SourceID:  dbDocument.SourceID,
+		Language:  dbDocument.Language,
+		ObjectKey: dbDocument.ObjectKey,
+		Checksum:  dbDocument.Checksum,
+		Size:      dbDocument.SizeBytes,
+		CreatedAt: dbDocument.CreatedAt.Time,
+		UpdatedAt: dbDocument.UpdatedAt.Time,
+	}
+}
@@ -0,0 +1,164 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/dunning"
+)
+
+// OpenDunningCase stores a new dunning case, returning false if the invoice
+// already has one
+func (r *Repository) OpenDunningCase(ctx context.Context, dunningCase *dunning.Case) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.OpenDunningCase")
+	defer span.End()
+
+	params := sqlc.OpenDunningCaseParams{
+		ID:             dunningCase.ID,
+		InvoiceID:      dunningCase.InvoiceID,
+		SubscriptionID: dunningCase.SubscriptionID,
+		CustomerID:     dunningCase.CustomerID,
+		AmountDue:      dunningCase.AmountDue,
+		Currency:       dunningCase.Currency,
+		State:          dunningCase.State,
+		LastError:      dunningCase.LastError,
+		FailedAt:       dunningCase.FailedAt,
+		TenantID:       scopedTenant(ctx),
+	}
+	if dunningCase.NextRetryAt != nil {
+		params.NextRetryAt = sql.NullTime{Time: *dunningCase.NextRetryAt, Valid: true}
+	}
+
+	rows, err := r.queries.OpenDunningCase(ctx, r.db, params)
+	if err != nil {
+		return false, fmt.Errorf("failed to open dunning case: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// GetDunningCase retrieves a dunning case by ID
+func (r *Repository) GetDunningCase(ctx context.Context, id string) (*dunning.Case, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetDunningCase")
+	defer span.End()
+
+	dbCase, err := r.queries.GetDunningCase(ctx, r.db, sqlc.GetDunningCaseParams{
+		ID:       id,
+		TenantID: scopedTenant(ctx),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dunning case: %w", err)
+	}
+
+	return convertDunningCase(dbCase), nil
+}
+
+// GetDunningCaseByInvoice retrieves the dunning case of an invoice
+func (r *Repository) GetDunningCaseByInvoice(ctx context.Context, invoiceID string) (*dunning.Case, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetDunningCaseByInvoice")
+	defer span.End()
+
+	dbCase, err := r.queries.GetDunningCaseByInvoice(ctx, r.db, invoiceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dunning case: %w", err)
+	}
+
+	return convertDunningCase(dbCase), nil
+}
+
+// ListDunningCases retrieves dunning cases, newest first
+func (r *Repository) ListDunningCases(ctx context.Context, filter dunning.Filter) ([]*dunning.Case, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListDunningCases")
+	defer span.End()
+
+	dbCases, err := r.queries.ListDunningCases(ctx, r.db, sqlc.ListDunningCasesParams{
+		State:          filter.State,
+		CustomerID:     filter.CustomerID,
+		SubscriptionID: filter.SubscriptionID,
+		Limit:          int32(filter.Limit),
+		TenantID:       scopedTenant(ctx),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dunning cases: %w", err)
+	}
+
+	return convertDunningCases(dbCases), nil
+}
+
+// ListOpenDunningCases retrieves unresolved dunning cases, oldest failure first
+func (r *Repository) ListOpenDunningCases(ctx context.Context, limit int) ([]*dunning.Case, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListOpenDunningCases")
+	defer span.End()
+
+	dbCases, err := r.queries.ListOpenDunningCases(ctx, r.db, int32(limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list open dunning cases: %w", err)
+	}
+
+	return convertDunningCases(dbCases), nil
+}
+
+// UpdateDunningCase stores the progress of an open dunning case. Resolved
+// cases are left as they are and return sql.ErrNoRows.
+func (r *Repository) UpdateDunningCase(ctx context.Context, dunningCase *dunning.Case) (*dunning.Case, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.UpdateDunningCase")
+	defer span.End()
+
+	params := sqlc.UpdateDunningCaseParams{
+		ID:        dunningCase.ID,
+		State:     dunningCase.State,
+		Attempts:  int32(dunningCase.Attempts),
+		LastError: dunningCase.LastError,
+	}
+	if dunningCase.NextRetryAt != nil {
+		params.NextRetryAt = sql.NullTime{Time: *dunningCase.NextRetryAt, Valid: true}
+	}
+	if dunningCase.ResolvedAt != nil {
+		params.ResolvedAt = sql.NullTime{Time: *dunningCase.ResolvedAt, Valid: true}
+	}
+
+	dbCase, err := r.queries.UpdateDunningCase(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update dunning case: %w", err)
+	}
+
+	return convertDunningCase(dbCase), nil
+}
+
+func convertDunningCases(dbCases []sqlc.DunningCase) []*dunning.Case {
+	cases := make([]*dunning.Case, len(dbCases))
+	for i, dbCase := range dbCases {
+		cases[i] = convertDunningCase(dbCase)
+	}
+	return cases
+}
+
+func convertDunningCase(dbCase sqlc.DunningCase) *dunning.Case {
+	dunningCase := &dunning.Case{
+		ID:             dbCase.ID,
+		InvoiceID:      dbCase.InvoiceID,
+		SubscriptionID: dbCase.SubscriptionID,
+		CustomerID:     dbCase.CustomerID,
+		AmountDue:      dbCase.AmountDue,
+		Currency:       dbCase.Currency,
+		State:          dbCase.State,
+		Attempts:       int(dbCase.Attempts),
+		LastError:      dbCase.LastError,
+		FailedAt:       dbCase.FailedAt,
+		CreatedAt:      dbCase.CreatedAt.Time,
+		UpdatedAt:      dbCase.UpdatedAt.Time,
+	}
+
+	if dbCase.NextRetryAt.Valid {
+		nextRetryAt := dbCase.NextRetryAt.Time
+		dunningCase.NextRetryAt = &nextRetryAt
+	}
+	if dbCase.ResolvedAt.Valid {
+		resolvedAt := dbCase.ResolvedAt.Time
+		dunningCase.ResolvedAt = &resolvedAt
+	}
+
+	return dunningCase
+}
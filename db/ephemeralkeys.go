@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/ephemeralkeys"
+)
+
+// CreateEphemeralKey stores an ephemeral key under the hash of its secret
+func (r *Repository) CreateEphemeralKey(ctx context.Context, key *ephemeralkeys.Key, secretHash string) (*ephemeralkeys.Key, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.CreateEphemeralKey")
+	defer span.End()
+
+	scopes, err := json.Marshal(key.Scopes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ephemeral key scopes: %w", err)
+	}
+
+	params := sqlc.CreateEphemeralKeyParams{
+		ID:         key.ID,
+		CustomerID: key.CustomerID,
+		SecretHash: secretHash,
+		Scopes:     scopes,
+		IssuedBy:   key.IssuedBy,
+		ExpiresAt:  key.ExpiresAt,
+	}
+
+	dbKey, err := r.queries.CreateEphemeralKey(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ephemeral key: %w", err)
+	}
+
+	return convertEphemeralKey(dbKey), nil
+}
+
+// GetEphemeralKeyBySecretHash retrieves an ephemeral key by the hash of its secret
+func (r *Repository) GetEphemeralKeyBySecretHash(ctx context.Context, secretHash string) (*ephemeralkeys.Key, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetEphemeralKeyBySecretHash")
+	defer span.End()
+
+	dbKey, err := r.queries.GetEphemeralKeyBySecretHash(ctx, r.db, secretHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ephemeral key: %w", err)
+	}
+
+	return convertEphemeralKey(dbKey), nil
+}
+
+// RevokeEphemeralKey revokes an ephemeral key, reporting whether an active key was revoked
+func (r *Repository) RevokeEphemeralKey(ctx context.Context, id string, revokedAt time.Time) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.RevokeEphemeralKey")
+	defer span.End()
+
+	params := sqlc.RevokeEphemeralKeyParams{
+		ID:        id,
+		RevokedAt: sql.NullTime{Time: revokedAt, Valid: true},
+	}
+
+	rows, err := r.queries.RevokeEphemeralKey(ctx, r.db, params)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke ephemeral key: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// convertEphemeralKey converts a database ephemeral key to a service ephemeral key
+func convertEphemeralKey(dbKey sqlc.EphemeralKey) *ephemeralkeys.Key {
+	key := &ephemeralkeys.Key{
+		ID:         dbKey.ID,
+		CustomerID: dbKey.CustomerID,
+		IssuedBy:   dbKey.IssuedBy,
+		ExpiresAt:  dbKey.ExpiresAt,
+		CreatedAt:  dbKey.CreatedAt.Time,
+	}
+
+	_ = json.Unmarshal(dbKey.Scopes, &key.Scopes)
+
+	if dbKey.RevokedAt.Valid {
+		revokedAt := dbKey.RevokedAt.Time
+		key.RevokedAt = &revokedAt
+	}
+
+	return key
+}
@@ -0,0 +1,45 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"apis/payments/db/sqlc"
+)
+
+// CountFingerprintCustomers counts the tenant's customers, other than the one
+// given, with a saved card of the fingerprint
+func (r *Repository) CountFingerprintCustomers(ctx context.Context, tenantID, fingerprint, excludeCustomerID string) (int64, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.CountFingerprintCustomers")
+	defer span.End()
+
+	params := sqlc.CountFingerprintCustomersParams{
+		TenantID:        tenantID,
+		CardFingerprint: sql.NullString{String: fingerprint, Valid: true},
+		CustomerID:      excludeCustomerID,
+	}
+
+	count, err := r.queries.CountFingerprintCustomers(ctx, r.db, params)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count fingerprint customers: %w", err)
+	}
+
+	return count, nil
+}
+
+// CountFingerprintChargebacks counts the disputes since a time, other than
+// won ones, on the tenant's charges to cards of the fingerprint
+func (r *Repository) CountFingerprintChargebacks(ctx context.Context, tenantID, fingerprint string, since time.Time) (int64, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.CountFingerprintChargebacks")
+	defer span.End()
+
+	params := sqlc.CountFingerprintChargebacksParams{Since: since, TenantID: tenantID, CardFingerprint: fingerprint}
+	count, err := r.queries.CountFingerprintChargebacks(ctx, r.db, params)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count fingerprint chargebacks: %w", err)
+	}
+
+	return count, nil
+}
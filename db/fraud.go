@@ -0,0 +1,340 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/fraud"
+)
+
+// RecordFraudScreening stores a charge screening and its decision
+func (r *Repository) RecordFraudScreening(ctx context.Context, screening *fraud.Screening) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.RecordFraudScreening")
+	defer span.End()
+
+	findings, err := json.Marshal(screening.Findings)
+	if err != nil {
+		return fmt.Errorf("failed to marshal screening findings: %w", err)
+	}
+
+	err = r.queries.RecordFraudScreening(ctx, r.db, sqlc.RecordFraudScreeningParams{
+		ID:              screening.ID,
+		TenantID:        screening.TenantID,
+		CustomerID:      screening.CustomerID,
+		CardFingerprint: screening.CardFingerprint,
+		IpAddress:       screening.IPAddress,
+		Amount:          screening.Amount,
+		Currency:        screening.Currency,
+		Decision:        screening.Decision,
+		Findings:        findings,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record fraud screening: %w", err)
+	}
+
+	return nil
+}
+
+// CountRecentFraudScreenings counts the screenings since a time that share a
+// screening's customer, card fingerprint or IP address
+func (r *Repository) CountRecentFraudScreenings(ctx context.Context, screening *fraud.Screening, since time.Time) (fraud.Counts, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.CountRecentFraudScreenings")
+	defer span.End()
+
+	row, err := r.queries.CountRecentFraudScreenings(ctx, r.db, sqlc.CountRecentFraudScreeningsParams{
+		CustomerID:      screening.CustomerID,
+		CardFingerprint: screening.CardFingerprint,
+		IpAddress:       screening.IPAddress,
+		Since:           sql.NullTime{Time: since, Valid: true},
+	})
+	if err != nil {
+		return fraud.Counts{}, fmt.Errorf("failed to count fraud screenings: %w", err)
+	}
+
+	return fraud.Counts{Customer: row.CustomerCount, Card: row.CardCount, IP: row.IpCount}, nil
+}
+
+// GetCustomerScreenedAmounts summarises a customer's accepted charges in a
+// currency since a time
+func (r *Repository) GetCustomerScreenedAmounts(ctx context.Context, tenantID, customerID, currency string, since time.Time) (fraud.AmountHistory, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetCustomerScreenedAmounts")
+	defer span.End()
+
+	row, err := r.queries.GetCustomerScreenedAmounts(ctx, r.db, sqlc.GetCustomerScreenedAmountsParams{
+		TenantID:   tenantID,
+		CustomerID: customerID,
+		Currency:   currency,
+		CreatedAt:  sql.NullTime{Time: since, Valid: true},
+	})
+	if err != nil {
+		return fraud.AmountHistory{}, fmt.Errorf("failed to get customer screened amounts: %w", err)
+	}
+
+	return fraud.AmountHistory{Count: row.ChargeCount, Average: row.AverageAmount}, nil
+}
+
+// CreateFraudListEntry stores an allow or deny list entry
+func (r *Repository) CreateFraudListEntry(ctx context.Context, entry *fraud.ListEntry) (*fraud.ListEntry, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.CreateFraudListEntry")
+	defer span.End()
+
+	dbEntry, err := r.queries.CreateFraudListEntry(ctx, r.db, sqlc.CreateFraudListEntryParams{
+		ID:        entry.ID,
+		List:      entry.List,
+		EntryType: entry.Type,
+		Value:     entry.Value,
+		Reason:    entry.Reason,
+		CreatedBy: entry.CreatedBy,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create fraud list entry: %w", err)
+	}
+
+	return convertFraudListEntry(dbEntry), nil
+}
+
+// ListFraudListEntries retrieves the entries on a list, or on both lists when list is empty
+func (r *Repository) ListFraudListEntries(ctx context.Context, list string) ([]*fraud.ListEntry, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListFraudListEntries")
+	defer span.End()
+
+	dbEntries, err := r.queries.ListFraudListEntries(ctx, r.db, list)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fraud list entries: %w", err)
+	}
+
+	return convertFraudListEntries(dbEntries), nil
+}
+
+// MatchFraudListEntries retrieves the list entries matching a screening's
+// customer, card fingerprint or IP address
+func (r *Repository) MatchFraudListEntries(ctx context.Context, screening *fraud.Screening) ([]*fraud.ListEntry, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.MatchFraudListEntries")
+	defer span.End()
+
+	dbEntries, err := r.queries.MatchFraudListEntries(ctx, r.db, sqlc.MatchFraudListEntriesParams{
+		CustomerID:      screening.CustomerID,
+		CardFingerprint: screening.CardFingerprint,
+		IpAddress:       screening.IPAddress,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to match fraud list entries: %w", err)
+	}
+
+	return convertFraudListEntries(dbEntries), nil
+}
+
+// DeleteFraudListEntry removes a list entry, reporting whether it existed
+func (r *Repository) DeleteFraudListEntry(ctx context.Context, id string) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.DeleteFraudListEntry")
+	defer span.End()
+
+	rows, err := r.queries.DeleteFraudListEntry(ctx, r.db, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete fraud list entry: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// RecordRadarScore stores the Radar risk score of a charge
+func (r *Repository) RecordRadarScore(ctx context.Context, score *fraud.RadarScore) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.RecordRadarScore")
+	defer span.End()
+
+	err := r.queries.RecordRadarScore(ctx, r.db, sqlc.RecordRadarScoreParams{
+		ChargeID:        score.ChargeID,
+		CustomerID:      score.CustomerID,
+		CardFingerprint: score.CardFingerprint,
+		RiskScore:       int32(score.RiskScore),
+		RiskLevel:       score.RiskLevel,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record Radar score: %w", err)
+	}
+
+	return nil
+}
+
+// GetLatestRadarScore retrieves the latest Radar score seen for a customer or
+// card since a time
+func (r *Repository) GetLatestRadarScore(ctx context.Context, customerID, cardFingerprint string, since time.Time) (*fraud.RadarScore, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetLatestRadarScore")
+	defer span.End()
+
+	dbScore, err := r.queries.GetLatestRadarScore(ctx, r.db, sqlc.GetLatestRadarScoreParams{
+		CustomerID:      customerID,
+		CardFingerprint: cardFingerprint,
+		Since:           sql.NullTime{Time: since, Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest Radar score: %w", err)
+	}
+
+	return &fraud.RadarScore{
+		ChargeID:        dbScore.ChargeID,
+		CustomerID:      dbScore.CustomerID,
+		CardFingerprint: dbScore.CardFingerprint,
+		RiskScore:       int64(dbScore.RiskScore),
+		RiskLevel:       dbScore.RiskLevel,
+		CreatedAt:       dbScore.CreatedAt.Time,
+	}, nil
+}
+
+// CreateFraudReview stores a charge held for review
+func (r *Repository) CreateFraudReview(ctx context.Context, review *fraud.Review) (*fraud.Review, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.CreateFraudReview")
+	defer span.End()
+
+	request, err := json.Marshal(review.Request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal review request: %w", err)
+	}
+	findings, err := json.Marshal(review.Findings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal review findings: %w", err)
+	}
+
+	dbReview, err := r.queries.CreateFraudReview(ctx, r.db, sqlc.CreateFraudReviewParams{
+		ID:          review.ID,
+		TenantID:    review.TenantID,
+		ScreeningID: review.ScreeningID,
+		CustomerID:  review.CustomerID,
+		Amount:      review.Amount,
+		Currency:    review.Currency,
+		Request:     request,
+		Findings:    findings,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create fraud review: %w", err)
+	}
+
+	return convertFraudReview(dbReview), nil
+}
+
+// GetFraudReview retrieves a fraud review
+func (r *Repository) GetFraudReview(ctx context.Context, id string) (*fraud.Review, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetFraudReview")
+	defer span.End()
+
+	dbReview, err := r.queries.GetFraudReview(ctx, r.db, sqlc.GetFraudReviewParams{
+		ID:       id,
+		TenantID: scopedTenant(ctx),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fraud review: %w", err)
+	}
+
+	return convertFraudReview(dbReview), nil
+}
+
+// ListFraudReviews retrieves a tenant's fraud reviews, newest first,
+// optionally filtered by status
+func (r *Repository) ListFraudReviews(ctx context.Context, tenantID, status string, limit int) ([]*fraud.Review, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListFraudReviews")
+	defer span.End()
+
+	dbReviews, err := r.queries.ListFraudReviews(ctx, r.db, sqlc.ListFraudReviewsParams{
+		TenantID: tenantID,
+		Status:   status,
+		Limit:    int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fraud reviews: %w", err)
+	}
+
+	reviews := make([]*fraud.Review, len(dbReviews))
+	for i, dbReview := range dbReviews {
+		reviews[i] = convertFraudReview(dbReview)
+	}
+
+	return reviews, nil
+}
+
+// DecideFraudReview moves a pending review to a decided status. It returns
+// sql.ErrNoRows if the review is not pending.
+func (r *Repository) DecideFraudReview(ctx context.Context, id, status, decidedBy string) (*fraud.Review, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.DecideFraudReview")
+	defer span.End()
+
+	dbReview, err := r.queries.DecideFraudReview(ctx, r.db, sqlc.DecideFraudReviewParams{
+		ID:        id,
+		Status:    status,
+		DecidedBy: decidedBy,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to decide fraud review: %w", err)
+	}
+
+	return convertFraudReview(dbReview), nil
+}
+
+// RecordFraudReviewCharge records the charge created for an approved review,
+// or why creating it failed
+func (r *Repository) RecordFraudReviewCharge(ctx context.Context, id, status, chargeID, failureReason string) (*fraud.Review, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.RecordFraudReviewCharge")
+	defer span.End()
+
+	dbReview, err := r.queries.RecordFraudReviewCharge(ctx, r.db, sqlc.RecordFraudReviewChargeParams{
+		ID:            id,
+		Status:        status,
+		ChargeID:      chargeID,
+		FailureReason: failureReason,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record fraud review charge: %w", err)
+	}
+
+	return convertFraudReview(dbReview), nil
+}
+
+// convertFraudListEntries converts database list entries to service entries
+func convertFraudListEntries(dbEntries []sqlc.FraudListEntry) []*fraud.ListEntry {
+	entries := make([]*fraud.ListEntry, len(dbEntries))
+	for i, dbEntry := range dbEntries {
+		entries[i] = convertFraudListEntry(dbEntry)
+	}
+	return entries
+}
+
+// convertFraudListEntry converts a database list entry to a service entry
+func convertFraudListEntry(dbEntry sqlc.FraudListEntry) *fraud.ListEntry {
+	return &fraud.ListEntry{
+		ID:        dbEntry.ID,
+		List:      dbEntry.List,
+		Type:      dbEntry.EntryType,
+		Value:     dbEntry.Value,
+		Reason:    dbEntry.Reason,
+		CreatedBy: dbEntry.CreatedBy,
+		CreatedAt: dbEntry.CreatedAt.Time,
+	}
+}
+
+// convertFraudReview converts a database fraud review to a service review
+func convertFraudReview(dbReview sqlc.FraudReview) *fraud.Review {
+	review := &fraud.Review{
+		ID:            dbReview.ID,
+		TenantID:      dbReview.TenantID,
+		ScreeningID:   dbReview.ScreeningID,
+		CustomerID:    dbReview.CustomerID,
+		Amount:        dbReview.Amount,
+		Currency:      dbReview.Currency,
+		Status:        dbReview.Status,
+		DecidedBy:     dbReview.DecidedBy,
+		ChargeID:      dbReview.ChargeID,
+		FailureReason: dbReview.FailureReason,
+		CreatedAt:     dbReview.CreatedAt.Time,
+		UpdatedAt:     dbReview.UpdatedAt.Time,
+	}
+	if dbReview.DecidedAt.Valid {
+		decidedAt := dbReview.DecidedAt.Time
+		review.DecidedAt = &decidedAt
+	}
+	_ = json.Unmarshal(dbReview.Request, &review.Request)
+	_ = json.Unmarshal(dbReview.Findings, &review.Findings)
+	return review
+}
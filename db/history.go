@@ -0,0 +1,84 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/history"
+)
+
+// RecordEntityVersion stores an immutable entity version
+func (r *Repository) RecordEntityVersion(ctx context.Context, version *history.Version) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.RecordEntityVersion")
+	defer span.End()
+
+	params := sqlc.RecordEntityVersionParams{
+		EntityType: version.EntityType,
+		EntityID:   version.EntityID,
+		Status:     version.Status,
+		State:      version.State,
+		EventID:    version.EventID,
+		EventType:  version.EventType,
+		ValidFrom:  version.ValidFrom,
+	}
+
+	if err := r.queries.RecordEntityVersion(ctx, r.db, params); err != nil {
+		return fmt.Errorf("failed to record entity version: %w", err)
+	}
+
+	return nil
+}
+
+// ListEntityVersions retrieves every version of an entity, oldest first
+func (r *Repository) ListEntityVersions(ctx context.Context, entityType, entityID string) ([]*history.Version, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListEntityVersions")
+	defer span.End()
+
+	dbVersions, err := r.queries.ListEntityVersions(ctx, r.db, sqlc.ListEntityVersionsParams{EntityType: entityType, EntityID: entityID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list entity versions: %w", err)
+	}
+
+	versions := make([]*history.Version, len(dbVersions))
+	for i, dbVersion := range dbVersions {
+		versions[i] = convertEntityVersion(dbVersion)
+	}
+
+	return versions, nil
+}
+
+// GetEntityVersionAsOf retrieves the version of an entity that was current at a point in time
+func (r *Repository) GetEntityVersionAsOf(ctx context.Context, entityType, entityID string, asOf time.Time) (*history.Version, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetEntityVersionAsOf")
+	defer span.End()
+
+	params := sqlc.GetEntityVersionAsOfParams{
+		EntityType: entityType,
+		EntityID:   entityID,
+		ValidFrom:  asOf,
+	}
+
+	dbVersion, err := r.queries.GetEntityVersionAsOf(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get entity version: %w", err)
+	}
+
+	return convertEntityVersion(dbVersion), nil
+}
+
+// convertEntityVersion converts a database entity version to a history version
+func convertEntityVersion(dbVersion sqlc.EntityVersion) *history.Version {
+	return &history.Version{
+		ID:         dbVersion.ID,
+		EntityType: dbVersion.EntityType,
+		EntityID:   dbVersion.EntityID,
+		Status:     dbVersion.Status,
+		State:      dbVersion.State,
+		EventID:    dbVersion.EventID,
+		EventType:  dbVersion.EventType,
+		ValidFrom:  dbVersion.ValidFrom,
+		RecordedAt: dbVersion.RecordedAt.Time,
+	}
+}
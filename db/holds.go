@@ -0,0 +1,211 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/holds"
+
+	"github.com/sqlc-dev/pqtype"
+)
+
+// GetHoldPolicy retrieves a tenant's hold policy
+func (r *Repository) GetHoldPolicy(ctx context.Context, tenantID string) (*holds.Policy, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetHoldPolicy")
+	defer span.End()
+
+	dbPolicy, err := r.queries.GetHoldPolicy(ctx, r.db, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hold policy: %w", err)
+	}
+
+	return convertHoldPolicy(dbPolicy), nil
+}
+
+// UpsertHoldPolicy creates or replaces a tenant's hold policy
+func (r *Repository) UpsertHoldPolicy(ctx context.Context, policy *holds.Policy) (*holds.Policy, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.UpsertHoldPolicy")
+	defer span.End()
+
+	params := sqlc.UpsertHoldPolicyParams{
+		TenantID:              policy.TenantID,
+		PauseOnDispute:        policy.PauseOnDispute,
+		PauseOnFraud:          policy.PauseOnFraud,
+		BlockChargesOnDispute: policy.BlockChargesOnDispute,
+		BlockChargesOnFraud:   policy.BlockChargesOnFraud,
+		MinRiskScore:          int32(policy.MinRiskScore),
+		AutoRelease:           policy.AutoRelease,
+	}
+
+	dbPolicy, err := r.queries.UpsertHoldPolicy(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert hold policy: %w", err)
+	}
+
+	return convertHoldPolicy(dbPolicy), nil
+}
+
+// CreateCustomerHold stores a customer hold in the database
+func (r *Repository) CreateCustomerHold(ctx context.Context, hold *holds.Hold) (*holds.Hold, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.CreateCustomerHold")
+	defer span.End()
+
+	var pausedSubscriptions pqtype.NullRawMessage
+	if len(hold.PausedSubscriptions) > 0 {
+		raw, err := json.Marshal(hold.PausedSubscriptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal paused subscriptions: %w", err)
+		}
+		pausedSubscriptions = pqtype.NullRawMessage{RawMessage: raw, Valid: true}
+	}
+
+	params := sqlc.CreateCustomerHoldParams{
+		ID:                  hold.ID,
+		TenantID:            hold.TenantID,
+		CustomerID:          hold.CustomerID,
+		Reason:              hold.Reason,
+		SourceID:            hold.SourceID,
+		Status:              hold.Status,
+		BlocksCharges:       hold.BlocksCharges,
+		PausedSubscriptions: pausedSubscriptions,
+	}
+
+	dbHold, err := r.queries.CreateCustomerHold(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create customer hold: %w", err)
+	}
+
+	return convertCustomerHold(dbHold), nil
+}
+
+// GetCustomerHold retrieves a customer hold from the database
+func (r *Repository) GetCustomerHold(ctx context.Context, id string) (*holds.Hold, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetCustomerHold")
+	defer span.End()
+
+	dbHold, err := r.queries.GetCustomerHold(ctx, r.db, sqlc.GetCustomerHoldParams{
+		ID:       id,
+		TenantID: scopedTenant(ctx),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer hold: %w", err)
+	}
+
+	return convertCustomerHold(dbHold), nil
+}
+
+// GetActiveCustomerHoldBySource retrieves the active hold placed for a dispute, review or fraud warning
+func (r *Repository) GetActiveCustomerHoldBySource(ctx context.Context, sourceID string) (*holds.Hold, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetActiveCustomerHoldBySource")
+	defer span.End()
+
+	dbHold, err := r.queries.GetActiveCustomerHoldBySource(ctx, r.db, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer hold: %w", err)
+	}
+
+	return convertCustomerHold(dbHold), nil
+}
+
+// ListActiveCustomerHolds lists the active holds for a customer
+func (r *Repository) ListActiveCustomerHolds(ctx context.Context, customerID string) ([]*holds.Hold, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListActiveCustomerHolds")
+	defer span.End()
+
+	dbHolds, err := r.queries.ListActiveCustomerHolds(ctx, r.db, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list customer holds: %w", err)
+	}
+
+	result := make([]*holds.Hold, len(dbHolds))
+	for i, dbHold := range dbHolds {
+		result[i] = convertCustomerHold(dbHold)
+	}
+
+	return result, nil
+}
+
+// ListCustomerHolds lists all holds for a customer
+func (r *Repository) ListCustomerHolds(ctx context.Context, customerID string) ([]*holds.Hold, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListCustomerHolds")
+	defer span.End()
+
+	dbHolds, err := r.queries.ListCustomerHolds(ctx, r.db, sqlc.ListCustomerHoldsParams{
+		CustomerID: customerID,
+		TenantID:   scopedTenant(ctx),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list customer holds: %w", err)
+	}
+
+	result := make([]*holds.Hold, len(dbHolds))
+	for i, dbHold := range dbHolds {
+		result[i] = convertCustomerHold(dbHold)
+	}
+
+	return result, nil
+}
+
+// ReleaseCustomerHold marks an active customer hold as released
+func (r *Repository) ReleaseCustomerHold(ctx context.Context, id, reason string) (*holds.Hold, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ReleaseCustomerHold")
+	defer span.End()
+
+	params := sqlc.ReleaseCustomerHoldParams{
+		ID:            id,
+		ReleaseReason: sql.NullString{String: reason, Valid: reason != ""},
+		TenantID:      scopedTenant(ctx),
+	}
+
+	dbHold, err := r.queries.ReleaseCustomerHold(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to release customer hold: %w", err)
+	}
+
+	return convertCustomerHold(dbHold), nil
+}
+
+// convertHoldPolicy converts a database hold policy to a holds.Policy
+func convertHoldPolicy(dbPolicy sqlc.HoldPolicy) *holds.Policy {
+	return &holds.Policy{
+		TenantID:              dbPolicy.TenantID,
+		PauseOnDispute:        dbPolicy.PauseOnDispute,
+		PauseOnFraud:          dbPolicy.PauseOnFraud,
+		BlockChargesOnDispute: dbPolicy.BlockChargesOnDispute,
+		BlockChargesOnFraud:   dbPolicy.BlockChargesOnFraud,
+		MinRiskScore:          int(dbPolicy.MinRiskScore),
+		AutoRelease:           dbPolicy.AutoRelease,
+		CreatedAt:             dbPolicy.CreatedAt.Time,
+		UpdatedAt:             dbPolicy.UpdatedAt.Time,
+	}
+}
+
+// convertCustomerHold converts a database customer hold to a holds.Hold
+func convertCustomerHold(dbHold sqlc.CustomerHold) *holds.Hold {
+	hold := &holds.Hold{
+		ID:            dbHold.ID,
+		TenantID:      dbHold.TenantID,
+		CustomerID:    dbHold.CustomerID,
+		Reason:        dbHold.Reason,
+		SourceID:      dbHold.SourceID,
+		Status:        dbHold.Status,
+		BlocksCharges: dbHold.BlocksCharges,
+		ReleaseReason: dbHold.ReleaseReason.String,
+		CreatedAt:     dbHold.CreatedAt.Time,
+		UpdatedAt:     dbHold.UpdatedAt.Time,
+	}
+
+	if dbHold.PausedSubscriptions.Valid {
+		_ = json.Unmarshal(dbHold.PausedSubscriptions.RawMessage, &hold.PausedSubscriptions)
+	}
+
+	if dbHold.ReleasedAt.Valid {
+		releasedAt := dbHold.ReleasedAt.Time
+		hold.ReleasedAt = &releasedAt
+	}
+
+	return hold
+}
@@ -0,0 +1,165 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/invoicing"
+	"apis/payments/services/stripe"
+)
+
+// UpsertInvoice stores an invoice unless a newer version is already stored
+func (r *Repository) UpsertInvoice(ctx context.Context, invoice *stripe.Invoice, syncedAt time.Time) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.UpsertInvoice")
+	defer span.End()
+
+	customFields, err := json.Marshal(invoice.CustomFields)
+	if err != nil {
+		return fmt.Errorf("failed to marshal invoice custom fields: %w", err)
+	}
+	metadata, err := json.Marshal(invoice.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal invoice metadata: %w", err)
+	}
+	lines, err := json.Marshal(invoice.Lines)
+	if err != nil {
+		return fmt.Errorf("failed to marshal invoice lines: %w", err)
+	}
+	taxAmounts, err := json.Marshal(invoice.TaxAmounts)
+	if err != nil {
+		return fmt.Errorf("failed to marshal invoice tax amounts: %w", err)
+	}
+
+	params := sqlc.UpsertInvoiceParams{
+		ID:               invoice.ID,
+		CustomerID:       invoice.CustomerID,
+		SubscriptionID:   invoice.SubscriptionID,
+		Number:           invoice.Number,
+		Status:           invoice.Status,
+		CollectionMethod: invoice.CollectionMethod,
+		Description:      invoice.Description,
+		AmountDue:        invoice.AmountDue,
+		AmountPaid:       invoice.AmountPaid,
+		AmountRemaining:  invoice.AmountRemaining,
+		Currency:         invoice.Currency,
+		PaidOutOfBand:    invoice.PaidOutOfBand,
+		HostedInvoiceUrl: invoice.HostedInvoiceURL,
+		InvoicePdf:       invoice.InvoicePDF,
+		CustomFields:     customFields,
+		Metadata:         metadata,
+		InvoicedAt:       time.Unix(invoice.Created, 0).UTC(),
+		SyncedAt:         syncedAt,
+		CustomerName:     invoice.CustomerName,
+		CustomerEmail:    invoice.CustomerEmail,
+		Subtotal:         invoice.Subtotal,
+		Tax:              invoice.Tax,
+		Total:            invoice.Total,
+		Lines:            lines,
+		TaxAmounts:       taxAmounts,
+		TenantID:         scopedTenant(ctx),
+	}
+	if invoice.DueDate != nil {
+		params.DueDate = sql.NullTime{Time: *invoice.DueDate, Valid: true}
+	}
+
+	if err := r.queries.UpsertInvoice(ctx, r.db, params); err != nil {
+		return fmt.Errorf("failed to upsert invoice: %w", err)
+	}
+
+	return nil
+}
+
+// GetInvoice retrieves an invoice by ID
+func (r *Repository) GetInvoice(ctx context.Context, invoiceID string) (*stripe.Invoice, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetInvoice")
+	defer span.End()
+
+	dbInvoice, err := r.queries.GetInvoice(ctx, r.db, sqlc.GetInvoiceParams{
+		ID:       invoiceID,
+		TenantID: scopedTenant(ctx),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invoice: %w", err)
+	}
+
+	return convertInvoice(dbInvoice), nil
+}
+
+// ListInvoices retrieves invoices matching a filter, newest first
+func (r *Repository) ListInvoices(ctx context.Context, filter invoicing.InvoiceFilter) ([]*stripe.Invoice, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListInvoices")
+	defer span.End()
+
+	params := sqlc.ListInvoicesParams{
+		CustomerID: filter.CustomerID,
+		Status:     filter.Status,
+		Limit:      int32(filter.Limit),
+		TenantID:   scopedTenant(ctx),
+	}
+
+	dbInvoices, err := r.queries.ListInvoices(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invoices: %w", err)
+	}
+
+	result := make([]*stripe.Invoice, len(dbInvoices))
+	for i, dbInvoice := range dbInvoices {
+		result[i] = convertInvoice(dbInvoice)
+	}
+
+	return result, nil
+}
+
+// DeleteInvoice removes a deleted draft invoice
+func (r *Repository) DeleteInvoice(ctx context.Context, invoiceID string) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.DeleteInvoice")
+	defer span.End()
+
+	if err := r.queries.DeleteInvoice(ctx, r.db, invoiceID); err != nil {
+		return fmt.Errorf("failed to delete invoice: %w", err)
+	}
+
+	return nil
+}
+
+// convertInvoice converts a database invoice to a service invoice
+func convertInvoice(dbInvoice sqlc.Invoice) *stripe.Invoice {
+	invoice := &stripe.Invoice{
+		ID:               dbInvoice.ID,
+		Number:           dbInvoice.Number,
+		CustomerID:       dbInvoice.CustomerID,
+		CustomerName:     dbInvoice.CustomerName,
+		CustomerEmail:    dbInvoice.CustomerEmail,
+		SubscriptionID:   dbInvoice.SubscriptionID,
+		Status:           dbInvoice.Status,
+		CollectionMethod: dbInvoice.CollectionMethod,
+		Description:      dbInvoice.Description,
+		AmountDue:        dbInvoice.AmountDue,
+		AmountPaid:       dbInvoice.AmountPaid,
+		AmountRemaining:  dbInvoice.AmountRemaining,
+		Subtotal:         dbInvoice.Subtotal,
+		Tax:              dbInvoice.Tax,
+		Total:            dbInvoice.Total,
+		Currency:         dbInvoice.Currency,
+		PaidOutOfBand:    dbInvoice.PaidOutOfBand,
+		HostedInvoiceURL: dbInvoice.HostedInvoiceUrl,
+		InvoicePDF:       dbInvoice.InvoicePdf,
+		Created:          dbInvoice.InvoicedAt.Unix(),
+	}
+
+	if dbInvoice.DueDate.Valid {
+		dueDate := dbInvoice.DueDate.Time
+		invoice.DueDate = &dueDate
+	}
+
+	_ = json.Unmarshal(dbInvoice.CustomFields, &invoice.CustomFields)
+	_ = json.Unmarshal(dbInvoice.Metadata, &invoice.Metadata)
+	_ = json.Unmarshal(dbInvoice.Lines, &invoice.Lines)
+	_ = json.Unmarshal(dbInvoice.TaxAmounts, &invoice.TaxAmounts)
+
+	return invoice
+}
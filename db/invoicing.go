@@ -0,0 +1,182 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/invoicing"
+)
+
+// GetReceivableInvoice retrieves a tracked invoice
+func (r *Repository) GetReceivableInvoice(ctx context.Context, invoiceID string) (*invoicing.Invoice, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetReceivableInvoice")
+	defer span.End()
+
+	dbInvoice, err := r.queries.GetReceivableInvoice(ctx, r.db, invoiceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get receivable invoice: %w", err)
+	}
+
+	return convertReceivableInvoice(dbInvoice), nil
+}
+
+// UpsertReceivableInvoice creates or updates a tracked invoice
+func (r *Repository) UpsertReceivableInvoice(ctx context.Context, invoice *invoicing.Invoice) (*invoicing.Invoice, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.UpsertReceivableInvoice")
+	defer span.End()
+
+	params := sqlc.UpsertReceivableInvoiceParams{
+		InvoiceID:       invoice.InvoiceID,
+		CustomerID:      invoice.CustomerID,
+		SubscriptionID:  invoice.SubscriptionID,
+		Number:          invoice.Number,
+		AmountDue:       invoice.AmountDue,
+		AmountRemaining: invoice.AmountRemaining,
+		Currency:        invoice.Currency,
+		DueDate:         invoice.DueDate,
+		Status:          invoice.Status,
+	}
+
+	dbInvoice, err := r.queries.UpsertReceivableInvoice(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert receivable invoice: %w", err)
+	}
+
+	return convertReceivableInvoice(dbInvoice), nil
+}
+
+// ListOpenReceivableInvoices retrieves every open tracked invoice
+func (r *Repository) ListOpenReceivableInvoices(ctx context.Context) ([]*invoicing.Invoice, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListOpenReceivableInvoices")
+	defer span.End()
+
+	dbInvoices, err := r.queries.ListOpenReceivableInvoices(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list open receivable invoices: %w", err)
+	}
+
+	return convertReceivableInvoices(dbInvoices), nil
+}
+
+// ListOverdueReceivableInvoices retrieves open invoices due before now, optionally for one customer
+func (r *Repository) ListOverdueReceivableInvoices(ctx context.Context, customerID string, now time.Time) ([]*invoicing.Invoice, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListOverdueReceivableInvoices")
+	defer span.End()
+
+	dbInvoices, err := r.queries.ListOverdueReceivableInvoices(ctx, r.db, sqlc.ListOverdueReceivableInvoicesParams{DueDate: now, CustomerID: customerID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list overdue receivable invoices: %w", err)
+	}
+
+	return convertReceivableInvoices(dbInvoices), nil
+}
+
+// MarkReceivableInvoiceOverdue records when an invoice became overdue
+func (r *Repository) MarkReceivableInvoiceOverdue(ctx context.Context, invoiceID string, at time.Time) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.MarkReceivableInvoiceOverdue")
+	defer span.End()
+
+	params := sqlc.MarkReceivableInvoiceOverdueParams{
+		InvoiceID: invoiceID,
+		OverdueAt: sql.NullTime{Time: at, Valid: true},
+	}
+
+	if err := r.queries.MarkReceivableInvoiceOverdue(ctx, r.db, params); err != nil {
+		return fmt.Errorf("failed to mark receivable invoice overdue: %w", err)
+	}
+
+	return nil
+}
+
+// MarkReceivableInvoicePaid records an offline payment of a tracked invoice
+func (r *Repository) MarkReceivableInvoicePaid(ctx context.Context, invoiceID, reference string, at time.Time) (*invoicing.Invoice, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.MarkReceivableInvoicePaid")
+	defer span.End()
+
+	params := sqlc.MarkReceivableInvoicePaidParams{
+		InvoiceID:     invoiceID,
+		PaidAt:        sql.NullTime{Time: at, Valid: true},
+		PaidReference: reference,
+	}
+
+	dbInvoice, err := r.queries.MarkReceivableInvoicePaid(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark receivable invoice paid: %w", err)
+	}
+
+	return convertReceivableInvoice(dbInvoice), nil
+}
+
+// ListInvoiceReminderOffsets retrieves the reminder offsets already sent for an invoice
+func (r *Repository) ListInvoiceReminderOffsets(ctx context.Context, invoiceID string) ([]int, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListInvoiceReminderOffsets")
+	defer span.End()
+
+	dbOffsets, err := r.queries.ListInvoiceReminderOffsets(ctx, r.db, invoiceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invoice reminders: %w", err)
+	}
+
+	offsets := make([]int, len(dbOffsets))
+	for i, offset := range dbOffsets {
+		offsets[i] = int(offset)
+	}
+
+	return offsets, nil
+}
+
+// RecordInvoiceReminder records that a reminder offset was sent for an invoice
+func (r *Repository) RecordInvoiceReminder(ctx context.Context, invoiceID string, offsetDays int) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.RecordInvoiceReminder")
+	defer span.End()
+
+	params := sqlc.RecordInvoiceReminderParams{
+		InvoiceID:  invoiceID,
+		OffsetDays: int32(offsetDays),
+	}
+
+	if err := r.queries.RecordInvoiceReminder(ctx, r.db, params); err != nil {
+		return fmt.Errorf("failed to record invoice reminder: %w", err)
+	}
+
+	return nil
+}
+
+// convertReceivableInvoices converts database receivable invoices to invoices
+func convertReceivableInvoices(dbInvoices []sqlc.ReceivableInvoice) []*invoicing.Invoice {
+	invoices := make([]*invoicing.Invoice, len(dbInvoices))
+	for i, dbInvoice := range dbInvoices {
+		invoices[i] = convertReceivableInvoice(dbInvoice)
+	}
+	return invoices
+}
+
+// convertReceivableInvoice converts a database receivable invoice to an invoice
+func convertReceivableInvoice(dbInvoice sqlc.ReceivableInvoice) *invoicing.Invoice {
+	invoice := &invoicing.Invoice{
+		InvoiceID:       dbInvoice.InvoiceID,
+		CustomerID:      dbInvoice.CustomerID,
+		SubscriptionID:  dbInvoice.SubscriptionID,
+		Number:          dbInvoice.Number,
+		AmountDue:       dbInvoice.AmountDue,
+		AmountRemaining: dbInvoice.AmountRemaining,
+		Currency:        dbInvoice.Currency,
+		DueDate:         dbInvoice.DueDate,
+		Status:          dbInvoice.Status,
+		PaidReference:   dbInvoice.PaidReference,
+		CreatedAt:       dbInvoice.CreatedAt.Time,
+		UpdatedAt:       dbInvoice.UpdatedAt.Time,
+	}
+	if dbInvoice.OverdueAt.Valid {
+		overdueAt := dbInvoice.OverdueAt.Time
+		invoice.OverdueAt = &overdueAt
+	}
+	if dbInvoice.PaidAt.Valid {
+		paidAt := dbInvoice.PaidAt.Time
+		invoice.PaidAt = &paidAt
+	}
+	return invoice
+}
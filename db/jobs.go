@@ -0,0 +1,142 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/jobs"
+)
+
+// CreateJobRun stores a new job run
+func (r *Repository) CreateJobRun(ctx context.Context, run *jobs.Run) (*jobs.Run, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.CreateJobRun")
+	defer span.End()
+
+	params := sqlc.CreateJobRunParams{
+		ID:          run.ID,
+		JobName:     run.Job,
+		Status:      run.Status,
+		TriggeredBy: run.TriggeredBy,
+		Attempts:    int32(run.Attempts),
+		Instance:    run.Instance,
+		StartedAt:   run.StartedAt,
+	}
+
+	dbRun, err := r.queries.CreateJobRun(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create job run: %w", err)
+	}
+
+	return convertJobRun(dbRun), nil
+}
+
+// FinishJobRun stores the outcome of a job run
+func (r *Repository) FinishJobRun(ctx context.Context, run *jobs.Run) (*jobs.Run, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.FinishJobRun")
+	defer span.End()
+
+	params := sqlc.FinishJobRunParams{
+		ID:       run.ID,
+		Status:   run.Status,
+		Attempts: int32(run.Attempts),
+		Error:    run.Error,
+	}
+	if run.FinishedAt != nil {
+		params.FinishedAt = sql.NullTime{Time: *run.FinishedAt, Valid: true}
+	}
+
+	dbRun, err := r.queries.FinishJobRun(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to finish job run: %w", err)
+	}
+
+	return convertJobRun(dbRun), nil
+}
+
+// AbandonJobRuns marks a job's runs still running as abandoned
+func (r *Repository) AbandonJobRuns(ctx context.Context, job string, at time.Time) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.AbandonJobRuns")
+	defer span.End()
+
+	params := sqlc.AbandonJobRunsParams{
+		JobName:    job,
+		FinishedAt: sql.NullTime{Time: at, Valid: true},
+	}
+
+	if err := r.queries.AbandonJobRuns(ctx, r.db, params); err != nil {
+		return fmt.Errorf("failed to abandon job runs: %w", err)
+	}
+
+	return nil
+}
+
+// ListJobRuns retrieves a job's most recent runs
+func (r *Repository) ListJobRuns(ctx context.Context, job string, limit int) ([]*jobs.Run, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListJobRuns")
+	defer span.End()
+
+	dbRuns, err := r.queries.ListJobRuns(ctx, r.db, sqlc.ListJobRunsParams{JobName: job, Limit: int32(limit)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list job runs: %w", err)
+	}
+
+	runs := make([]*jobs.Run, len(dbRuns))
+	for i, dbRun := range dbRuns {
+		runs[i] = convertJobRun(dbRun)
+	}
+
+	return runs, nil
+}
+
+// TryJobLock takes a session-level Postgres advisory lock on a job without
+// waiting. The lock lives on a pooled connection held until unlock, so it is
+// released by Postgres if the instance holding it goes away.
+func (r *Repository) TryJobLock(ctx context.Context, job string) (unlock func(), locked bool, err error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.TryJobLock")
+	defer span.End()
+
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+
+	if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock(hashtext('payments.jobs'), hashtext($1))", job).Scan(&locked); err != nil {
+		conn.Release()
+		return nil, false, fmt.Errorf("failed to lock job: %w", err)
+	}
+	if !locked {
+		conn.Release()
+		return nil, false, nil
+	}
+
+	unlock = func() {
+		defer conn.Release()
+		if _, err := conn.Exec(context.Background(), "SELECT pg_advisory_unlock(hashtext('payments.jobs'), hashtext($1))", job); err != nil {
+			log.Printf("Failed to unlock job %s: %v", job, err)
+		}
+	}
+
+	return unlock, true, nil
+}
+
+// convertJobRun converts a database job run to a jobs.Run
+func convertJobRun(dbRun sqlc.JobRun) *jobs.Run {
+	run := &jobs.Run{
+		ID:          dbRun.ID,
+		Job:         dbRun.JobName,
+		Status:      dbRun.Status,
+		TriggeredBy: dbRun.TriggeredBy,
+		Attempts:    int(dbRun.Attempts),
+		Error:       dbRun.Error,
+		Instance:    dbRun.Instance,
+		StartedAt:   dbRun.StartedAt,
+	}
+	if dbRun.FinishedAt.Valid {
+		run.FinishedAt = &dbRun.FinishedAt.Time
+	}
+	return run
+}
@@ -0,0 +1,122 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/ledger"
+)
+
+// CreateLedgerEntry stores a ledger entry, ignoring reposts for the same reference
+func (r *Repository) CreateLedgerEntry(ctx context.Context, entry *ledger.Entry) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.CreateLedgerEntry")
+	defer span.End()
+
+	params := sqlc.CreateLedgerEntryParams{
+		ID:            entry.ID,
+		DebitAccount:  entry.DebitAccount,
+		CreditAccount: entry.CreditAccount,
+		Amount:        entry.Amount,
+		Currency:      entry.Currency,
+		ReferenceType: entry.ReferenceType,
+		ReferenceID:   entry.ReferenceID,
+		Description:   entry.Description,
+	}
+
+	if err := r.queries.CreateLedgerEntry(ctx, r.db, params); err != nil {
+		return fmt.Errorf("failed to create ledger entry: %w", err)
+	}
+
+	return nil
+}
+
+// ListLedgerEntriesByReference retrieves the entries posted for a reference
+func (r *Repository) ListLedgerEntriesByReference(ctx context.Context, referenceType, referenceID string) ([]*ledger.Entry, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListLedgerEntriesByReference")
+	defer span.End()
+
+	params := sqlc.ListLedgerEntriesByReferenceParams{
+		ReferenceType: referenceType,
+		ReferenceID:   referenceID,
+	}
+
+	dbEntries, err := r.queries.ListLedgerEntriesByReference(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ledger entries: %w", err)
+	}
+
+	entries := make([]*ledger.Entry, len(dbEntries))
+	for i, dbEntry := range dbEntries {
+		entries[i] = convertLedgerEntry(dbEntry)
+	}
+
+	return entries, nil
+}
+
+// ListLedgerEntriesByAccount retrieves a page of the entries debiting or
+// crediting an account, newest first. An empty currency matches every currency.
+func (r *Repository) ListLedgerEntriesByAccount(ctx context.Context, account, currency string, limit, offset int) ([]*ledger.Entry, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListLedgerEntriesByAccount")
+	defer span.End()
+
+	params := sqlc.ListLedgerEntriesByAccountParams{
+		Account:  account,
+		Currency: currency,
+		Limit:    int32(limit),
+		Offset:   int32(offset),
+	}
+
+	dbEntries, err := r.queries.ListLedgerEntriesByAccount(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ledger entries for account: %w", err)
+	}
+
+	entries := make([]*ledger.Entry, len(dbEntries))
+	for i, dbEntry := range dbEntries {
+		entries[i] = convertLedgerEntry(dbEntry)
+	}
+
+	return entries, nil
+}
+
+// ListLedgerAccountTotals sums the debits and credits posted to each account
+// per currency up to a point in time
+func (r *Repository) ListLedgerAccountTotals(ctx context.Context, asOf time.Time) ([]*ledger.AccountTotal, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListLedgerAccountTotals")
+	defer span.End()
+
+	rows, err := r.queries.ListLedgerAccountTotals(ctx, r.db, sql.NullTime{Time: asOf, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to total ledger accounts: %w", err)
+	}
+
+	totals := make([]*ledger.AccountTotal, len(rows))
+	for i, row := range rows {
+		totals[i] = &ledger.AccountTotal{
+			Account:  row.Account,
+			Currency: row.Currency,
+			Debits:   row.Debits,
+			Credits:  row.Credits,
+		}
+	}
+
+	return totals, nil
+}
+
+// convertLedgerEntry converts a database ledger entry to a ledger entry
+func convertLedgerEntry(dbEntry sqlc.LedgerEntry) *ledger.Entry {
+	return &ledger.Entry{
+		ID:            dbEntry.ID,
+		DebitAccount:  dbEntry.DebitAccount,
+		CreditAccount: dbEntry.CreditAccount,
+		Amount:        dbEntry.Amount,
+		Currency:      dbEntry.Currency,
+		ReferenceType: dbEntry.ReferenceType,
+		ReferenceID:   dbEntry.ReferenceID,
+		Description:   dbEntry.Description,
+		CreatedAt:     dbEntry.CreatedAt.Time,
+	}
+}
@@ -0,0 +1,325 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/merchantwebhooks"
+)
+
+// CreateWebhookEndpoint stores a tenant's webhook endpoint
+func (r *Repository) CreateWebhookEndpoint(ctx context.Context, endpoint *merchantwebhooks.Endpoint) (*merchantwebhooks.Endpoint, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.CreateWebhookEndpoint")
+	defer span.End()
+
+	eventTypes, err := json.Marshal(endpoint.EventTypes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal webhook endpoint event types: %w", err)
+	}
+
+	dbEndpoint, err := r.queries.CreateWebhookEndpoint(ctx, r.db, sqlc.CreateWebhookEndpointParams{
+		ID:               endpoint.ID,
+		TenantID:         endpoint.TenantID,
+		Url:              endpoint.URL,
+		Description:      endpoint.Description,
+		EventTypes:       eventTypes,
+		SecretCiphertext: endpoint.Ciphertext,
+		Enabled:          endpoint.Enabled,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook endpoint: %w", err)
+	}
+
+	return convertWebhookEndpoint(dbEndpoint)
+}
+
+// GetWebhookEndpoint retrieves a tenant's webhook endpoint
+func (r *Repository) GetWebhookEndpoint(ctx context.Context, tenantID, id string) (*merchantwebhooks.Endpoint, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetWebhookEndpoint")
+	defer span.End()
+
+	dbEndpoint, err := r.queries.GetWebhookEndpoint(ctx, r.db, sqlc.GetWebhookEndpointParams{
+		TenantID: tenantID,
+		ID:       id,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook endpoint: %w", err)
+	}
+
+	return convertWebhookEndpoint(dbEndpoint)
+}
+
+// ListWebhookEndpoints retrieves a tenant's webhook endpoints, oldest first
+func (r *Repository) ListWebhookEndpoints(ctx context.Context, tenantID string) ([]*merchantwebhooks.Endpoint, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListWebhookEndpoints")
+	defer span.End()
+
+	dbEndpoints, err := r.queries.ListWebhookEndpoints(ctx, r.db, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook endpoints: %w", err)
+	}
+
+	endpoints := make([]*merchantwebhooks.Endpoint, len(dbEndpoints))
+	for i, dbEndpoint := range dbEndpoints {
+		if endpoints[i], err = convertWebhookEndpoint(dbEndpoint); err != nil {
+			return nil, err
+		}
+	}
+	return endpoints, nil
+}
+
+// UpdateWebhookEndpoint stores changes to a tenant's webhook endpoint
+func (r *Repository) UpdateWebhookEndpoint(ctx context.Context, endpoint *merchantwebhooks.Endpoint) (*merchantwebhooks.Endpoint, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.UpdateWebhookEndpoint")
+	defer span.End()
+
+	eventTypes, err := json.Marshal(endpoint.EventTypes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal webhook endpoint event types: %w", err)
+	}
+
+	dbEndpoint, err := r.queries.UpdateWebhookEndpoint(ctx, r.db, sqlc.UpdateWebhookEndpointParams{
+		TenantID:    endpoint.TenantID,
+		ID:          endpoint.ID,
+		Url:         endpoint.URL,
+		Description: endpoint.Description,
+		EventTypes:  eventTypes,
+		Enabled:     endpoint.Enabled,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update webhook endpoint: %w", err)
+	}
+
+	return convertWebhookEndpoint(dbEndpoint)
+}
+
+// DeleteWebhookEndpoint removes a tenant's webhook endpoint and its
+// deliveries, reporting whether it existed
+func (r *Repository) DeleteWebhookEndpoint(ctx context.Context, tenantID, id string) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.DeleteWebhookEndpoint")
+	defer span.End()
+
+	rows, err := r.queries.DeleteWebhookEndpoint(ctx, r.db, sqlc.DeleteWebhookEndpointParams{
+		TenantID: tenantID,
+		ID:       id,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to delete webhook endpoint: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// CreateWebhookDelivery queues an event for an endpoint, returning the
+// delivery already queued if the event was queued before
+func (r *Repository) CreateWebhookDelivery(ctx context.Context, delivery *merchantwebhooks.Delivery) (*merchantwebhooks.Delivery, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.CreateWebhookDelivery")
+	defer span.End()
+
+	params := sqlc.CreateWebhookDeliveryParams{
+		ID:         delivery.ID,
+		TenantID:   delivery.TenantID,
+		EndpointID: delivery.EndpointID,
+		EventID:    delivery.EventID,
+		EventType:  delivery.EventType,
+		Payload:    delivery.Payload,
+	}
+	if delivery.NextAttemptAt != nil {
+		params.NextAttemptAt = sql.NullTime{Time: *delivery.NextAttemptAt, Valid: true}
+	}
+
+	dbDelivery, err := r.queries.CreateWebhookDelivery(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+
+	return convertWebhookDelivery(dbDelivery), nil
+}
+
+// GetWebhookDelivery retrieves a tenant's webhook delivery
+func (r *Repository) GetWebhookDelivery(ctx context.Context, tenantID, id string) (*merchantwebhooks.Delivery, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetWebhookDelivery")
+	defer span.End()
+
+	dbDelivery, err := r.queries.GetWebhookDelivery(ctx, r.db, sqlc.GetWebhookDeliveryParams{
+		TenantID: tenantID,
+		ID:       id,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+
+	return convertWebhookDelivery(dbDelivery), nil
+}
+
+// ListWebhookDeliveries retrieves an endpoint's deliveries, newest first
+func (r *Repository) ListWebhookDeliveries(ctx context.Context, tenantID, endpointID, status string, limit int) ([]*merchantwebhooks.Delivery, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListWebhookDeliveries")
+	defer span.End()
+
+	dbDeliveries, err := r.queries.ListWebhookDeliveries(ctx, r.db, sqlc.ListWebhookDeliveriesParams{
+		TenantID:   tenantID,
+		EndpointID: endpointID,
+		Status:     status,
+		Limit:      int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	return convertWebhookDeliveries(dbDeliveries), nil
+}
+
+// ClaimDueWebhookDeliveries leases pending deliveries whose next attempt is
+// due, skipping deliveries other workers hold
+func (r *Repository) ClaimDueWebhookDeliveries(ctx context.Context, leaseUntil time.Time, limit int) ([]*merchantwebhooks.Delivery, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ClaimDueWebhookDeliveries")
+	defer span.End()
+
+	dbDeliveries, err := r.queries.ClaimDueWebhookDeliveries(ctx, r.db, sqlc.ClaimDueWebhookDeliveriesParams{
+		NextAttemptAt: sql.NullTime{Time: leaseUntil, Valid: true},
+		Limit:         int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+
+	return convertWebhookDeliveries(dbDeliveries), nil
+}
+
+// UpdateWebhookDelivery records the outcome of a delivery attempt
+func (r *Repository) UpdateWebhookDelivery(ctx context.Context, delivery *merchantwebhooks.Delivery) (*merchantwebhooks.Delivery, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.UpdateWebhookDelivery")
+	defer span.End()
+
+	params := sqlc.UpdateWebhookDeliveryParams{
+		ID:             delivery.ID,
+		Status:         delivery.Status,
+		Attempts:       int32(delivery.Attempts),
+		LastStatusCode: int32(delivery.LastStatusCode),
+		LastError:      delivery.LastError,
+	}
+	if delivery.NextAttemptAt != nil {
+		params.NextAttemptAt = sql.NullTime{Time: *delivery.NextAttemptAt, Valid: true}
+	}
+	if delivery.DeliveredAt != nil {
+		params.DeliveredAt = sql.NullTime{Time: *delivery.DeliveredAt, Valid: true}
+	}
+
+	dbDelivery, err := r.queries.UpdateWebhookDelivery(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+
+	return convertWebhookDelivery(dbDelivery), nil
+}
+
+// CreateWebhookDeliveryAttempt logs an attempt at sending a delivery
+func (r *Repository) CreateWebhookDeliveryAttempt(ctx context.Context, attempt *merchantwebhooks.Attempt) (*merchantwebhooks.Attempt, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.CreateWebhookDeliveryAttempt")
+	defer span.End()
+
+	dbAttempt, err := r.queries.CreateWebhookDeliveryAttempt(ctx, r.db, sqlc.CreateWebhookDeliveryAttemptParams{
+		ID:         attempt.ID,
+		DeliveryID: attempt.DeliveryID,
+		StatusCode: int32(attempt.StatusCode),
+		Error:      attempt.Error,
+		DurationMs: attempt.DurationMS,
+		Manual:     attempt.Manual,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook delivery attempt: %w", err)
+	}
+
+	return convertWebhookDeliveryAttempt(dbAttempt), nil
+}
+
+// ListWebhookDeliveryAttempts retrieves a delivery's attempts, oldest first
+func (r *Repository) ListWebhookDeliveryAttempts(ctx context.Context, deliveryID string) ([]*merchantwebhooks.Attempt, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListWebhookDeliveryAttempts")
+	defer span.End()
+
+	dbAttempts, err := r.queries.ListWebhookDeliveryAttempts(ctx, r.db, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook delivery attempts: %w", err)
+	}
+
+	attempts := make([]*merchantwebhooks.Attempt, len(dbAttempts))
+	for i, dbAttempt := range dbAttempts {
+		attempts[i] = convertWebhookDeliveryAttempt(dbAttempt)
+	}
+	return attempts, nil
+}
+
+// convertWebhookEndpoint converts a database webhook endpoint
+func convertWebhookEndpoint(dbEndpoint sqlc.WebhookEndpoint) (*merchantwebhooks.Endpoint, error) {
+	var eventTypes []string
+	if len(dbEndpoint.EventTypes) > 0 {
+		if err := json.Unmarshal(dbEndpoint.EventTypes, &eventTypes); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal webhook endpoint event types: %w", err)
+		}
+	}
+
+	return &merchantwebhooks.Endpoint{
+		ID:          dbEndpoint.ID,
+		TenantID:    dbEndpoint.TenantID,
+		URL:         dbEndpoint.Url,
+		Description: dbEndpoint.Description,
+		EventTypes:  eventTypes,
+		Enabled:     dbEndpoint.Enabled,
+		Ciphertext:  dbEndpoint.SecretCiphertext,
+		CreatedAt:   dbEndpoint.CreatedAt.Time,
+		UpdatedAt:   dbEndpoint.UpdatedAt.Time,
+	}, nil
+}
+
+// convertWebhookDeliveries converts database webhook deliveries
+func convertWebhookDeliveries(dbDeliveries []sqlc.WebhookDelivery) []*merchantwebhooks.Delivery {
+	deliveries := make([]*merchantwebhooks.Delivery, len(dbDeliveries))
+	for i, dbDelivery := range dbDeliveries {
+		deliveries[i] = convertWebhookDelivery(dbDelivery)
+	}
+	return deliveries
+}
+
+// convertWebhookDelivery converts a database webhook delivery
+func convertWebhookDelivery(dbDelivery sqlc.WebhookDelivery) *merchantwebhooks.Delivery {
+	delivery := &merchantwebhooks.Delivery{
+		ID:             dbDelivery.ID,
+		TenantID:       dbDelivery.TenantID,
+		EndpointID:     dbDelivery.EndpointID,
+		EventID:        dbDelivery.EventID,
+		EventType:      dbDelivery.EventType,
+		Payload:        dbDelivery.Payload,
+		Status:         dbDelivery.Status,
+		Attempts:       int(dbDelivery.Attempts),
+		LastStatusCode: int(dbDelivery.LastStatusCode),
+		LastError:      dbDelivery.LastError,
+		CreatedAt:      dbDelivery.CreatedAt.Time,
+		UpdatedAt:      dbDelivery.UpdatedAt.Time,
+	}
+	if dbDelivery.NextAttemptAt.Valid {
+		delivery.NextAttemptAt = &dbDelivery.NextAttemptAt.Time
+	}
+	if dbDelivery.DeliveredAt.Valid {
+		delivery.DeliveredAt = &dbDelivery.DeliveredAt.Time
+	}
+	return delivery
+}
+
+// convertWebhookDeliveryAttempt converts a database webhook delivery attempt
+func convertWebhookDeliveryAttempt(dbAttempt sqlc.WebhookDeliveryAttempt) *merchantwebhooks.Attempt {
+	return &merchantwebhooks.Attempt{
+		ID:          dbAttempt.ID,
+		DeliveryID:  dbAttempt.DeliveryID,
+		StatusCode:  int(dbAttempt.StatusCode),
+		Error:       dbAttempt.Error,
+		DurationMS:  dbAttempt.DurationMs,
+		Manual:      dbAttempt.Manual,
+		AttemptedAt: dbAttempt.AttemptedAt.Time,
+	}
+}
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/metadata"
+)
+
+// GetMetadataSchema retrieves a tenant's metadata schema for a resource type
+func (r *Repository) GetMetadataSchema(ctx context.Context, tenantID, resource string) (*metadata.Registration, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetMetadataSchema")
+	defer span.End()
+
+	dbSchema, err := r.queries.GetMetadataSchema(ctx, r.db, sqlc.GetMetadataSchemaParams{TenantID: tenantID, Resource: resource})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metadata schema: %w", err)
+	}
+
+	return convertMetadataSchema(dbSchema)
+}
+
+// ListMetadataSchemas retrieves every metadata schema registered by a tenant
+func (r *Repository) ListMetadataSchemas(ctx context.Context, tenantID string) ([]*metadata.Registration, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListMetadataSchemas")
+	defer span.End()
+
+	dbSchemas, err := r.queries.ListMetadataSchemas(ctx, r.db, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list metadata schemas: %w", err)
+	}
+
+	registrations := make([]*metadata.Registration, len(dbSchemas))
+	for i, dbSchema := range dbSchemas {
+		registration, err := convertMetadataSchema(dbSchema)
+		if err != nil {
+			return nil, err
+		}
+		registrations[i] = registration
+	}
+
+	return registrations, nil
+}
+
+// UpsertMetadataSchema creates or replaces a tenant's metadata schema for a resource type
+func (r *Repository) UpsertMetadataSchema(ctx context.Context, registration *metadata.Registration) (*metadata.Registration, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.UpsertMetadataSchema")
+	defer span.End()
+
+	raw, err := json.Marshal(registration.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal metadata schema: %w", err)
+	}
+
+	params := sqlc.UpsertMetadataSchemaParams{
+		TenantID: registration.TenantID,
+		Resource: registration.Resource,
+		Schema:   raw,
+	}
+
+	dbSchema, err := r.queries.UpsertMetadataSchema(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert metadata schema: %w", err)
+	}
+
+	return convertMetadataSchema(dbSchema)
+}
+
+// DeleteMetadataSchema removes a tenant's metadata schema, reporting whether one existed
+func (r *Repository) DeleteMetadataSchema(ctx context.Context, tenantID, resource string) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.DeleteMetadataSchema")
+	defer span.End()
+
+	rows, err := r.queries.DeleteMetadataSchema(ctx, r.db, sqlc.DeleteMetadataSchemaParams{TenantID: tenantID, Resource: resource})
+	if err != nil {
+		return false, fmt.Errorf("failed to delete metadata schema: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// convertMetadataSchema converts a database metadata schema to a registration
+func convertMetadataSchema(dbSchema sqlc.MetadataSchema) (*metadata.Registration, error) {
+	var schema metadata.Schema
+	if err := json.Unmarshal(dbSchema.Schema, &schema); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal metadata schema: %w", err)
+	}
+
+	return &metadata.Registration{
+		TenantID:  dbSchema.TenantID,
+		Resource:  dbSchema.Resource,
+		Schema:    &schema,
+		CreatedAt: dbSchema.CreatedAt.Time,
+		UpdatedAt: dbSchema.UpdatedAt.Time,
+	}, nil
+}
@@ -0,0 +1,46 @@
+-- Migration to add customer holds
+-- This creates per-tenant hold policies and the holds placed on customers
+-- after disputes or fraud flags
+
+-- Create hold_policies table
+CREATE TABLE IF NOT EXISTS hold_policies (
+    tenant_id VARCHAR(255) PRIMARY KEY,
+    pause_on_dispute BOOLEAN NOT NULL DEFAULT TRUE,
+    pause_on_fraud BOOLEAN NOT NULL DEFAULT TRUE,
+    block_charges_on_dispute BOOLEAN NOT NULL DEFAULT TRUE,
+    block_charges_on_fraud BOOLEAN NOT NULL DEFAULT TRUE,
+    min_risk_score INTEGER NOT NULL DEFAULT 75,
+    auto_release BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create customer_holds table
+CREATE TABLE IF NOT EXISTS customer_holds (
+    id VARCHAR(255) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    customer_id VARCHAR(255) NOT NULL,
+    reason VARCHAR(50) NOT NULL,
+    source_id VARCHAR(255) NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    blocks_charges BOOLEAN NOT NULL,
+    paused_subscriptions JSONB,
+    release_reason VARCHAR(100),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    released_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_customer_holds_customer_id ON customer_holds(customer_id);
+CREATE INDEX IF NOT EXISTS idx_customer_holds_source_id ON customer_holds(source_id);
+CREATE INDEX IF NOT EXISTS idx_customer_holds_status ON customer_holds(status);
+
+-- Create triggers for updated_at
+CREATE TRIGGER update_hold_policies_updated_at 
+    BEFORE UPDATE ON hold_policies 
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_customer_holds_updated_at 
+    BEFORE UPDATE ON customer_holds 
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
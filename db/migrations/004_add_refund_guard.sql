@@ -0,0 +1,44 @@
+-- Migration to add refund velocity tracking
+-- This creates the refund activity log used to baseline refund volume and
+-- the approvals required when refund volume looks anomalous
+
+-- Create refund_activity table
+CREATE TABLE IF NOT EXISTS refund_activity (
+    id VARCHAR(255) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    api_key_id VARCHAR(255) NOT NULL,
+    operator_id VARCHAR(255) NOT NULL,
+    refund_id VARCHAR(255) NOT NULL,
+    charge_id VARCHAR(255) NOT NULL,
+    amount BIGINT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create refund_approvals table
+CREATE TABLE IF NOT EXISTS refund_approvals (
+    id VARCHAR(255) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    api_key_id VARCHAR(255) NOT NULL,
+    operator_id VARCHAR(255) NOT NULL,
+    request JSONB NOT NULL,
+    amount BIGINT NOT NULL,
+    reasons JSONB NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    decided_by VARCHAR(255),
+    refund_id VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    decided_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_refund_activity_tenant_created ON refund_activity(tenant_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_refund_activity_api_key_created ON refund_activity(api_key_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_refund_activity_operator_created ON refund_activity(operator_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_refund_approvals_tenant_status ON refund_approvals(tenant_id, status);
+
+-- Create trigger for updated_at
+CREATE TRIGGER update_refund_approvals_updated_at 
+    BEFORE UPDATE ON refund_approvals 
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,15 @@
+-- Migration to add processed webhook events
+-- This records every provider event that has been handled so redelivered
+-- events are skipped and catch-up runs know where to resume
+
+-- Create webhook_events table
+CREATE TABLE IF NOT EXISTS webhook_events (
+    id VARCHAR(255) PRIMARY KEY,
+    type VARCHAR(100) NOT NULL,
+    created BIGINT NOT NULL,
+    source VARCHAR(50) NOT NULL,
+    processed_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_webhook_events_created ON webhook_events(created);
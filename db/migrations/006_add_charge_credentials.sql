@@ -0,0 +1,30 @@
+-- Migration to add charge credential tracking
+-- This records which card credential each charge was authorized with
+-- (network token, wallet DPAN or raw PAN) for cost analytics and retries
+
+-- Create charge_credentials table
+CREATE TABLE IF NOT EXISTS charge_credentials (
+    charge_id VARCHAR(255) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL DEFAULT 'default',
+    customer_id VARCHAR(255) NOT NULL,
+    payment_method_id VARCHAR(255) NOT NULL DEFAULT '',
+    network VARCHAR(50) NOT NULL DEFAULT '',
+    credential_type VARCHAR(50) NOT NULL,
+    wallet VARCHAR(50) NOT NULL DEFAULT '',
+    amount BIGINT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    failure_code VARCHAR(100) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_charge_credentials_customer_id ON charge_credentials(customer_id);
+CREATE INDEX IF NOT EXISTS idx_charge_credentials_created_at ON charge_credentials(created_at);
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_charge_credentials_updated_at
+    BEFORE UPDATE ON charge_credentials
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
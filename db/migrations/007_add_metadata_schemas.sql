@@ -0,0 +1,19 @@
+-- Migration to add metadata schemas
+-- This stores per-tenant JSON schemas that metadata on each resource type
+-- is validated against
+
+-- Create metadata_schemas table
+CREATE TABLE IF NOT EXISTS metadata_schemas (
+    tenant_id VARCHAR(255) NOT NULL,
+    resource VARCHAR(50) NOT NULL,
+    schema JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, resource)
+);
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_metadata_schemas_updated_at
+    BEFORE UPDATE ON metadata_schemas
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,35 @@
+-- Migration to add the charge list read model
+-- This stores one denormalized row per charge, kept up to date from
+-- provider events, so list endpoints read a single indexed table instead
+-- of joining provider data per request
+
+-- Create charge_list_rows table
+CREATE TABLE IF NOT EXISTS charge_list_rows (
+    charge_id VARCHAR(255) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL DEFAULT 'default',
+    customer_id VARCHAR(255) NOT NULL DEFAULT '',
+    customer_email VARCHAR(255) NOT NULL DEFAULT '',
+    customer_name VARCHAR(255) NOT NULL DEFAULT '',
+    plan_name VARCHAR(255) NOT NULL DEFAULT '',
+    amount BIGINT NOT NULL,
+    amount_refunded BIGINT NOT NULL DEFAULT 0,
+    currency VARCHAR(3) NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    last_refund_id VARCHAR(255) NOT NULL DEFAULT '',
+    last_refund_status VARCHAR(50) NOT NULL DEFAULT '',
+    charge_created TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_charge_list_rows_tenant_created ON charge_list_rows(tenant_id, charge_created DESC);
+CREATE INDEX IF NOT EXISTS idx_charge_list_rows_customer_created ON charge_list_rows(customer_id, charge_created DESC);
+CREATE INDEX IF NOT EXISTS idx_charge_list_rows_tenant_status ON charge_list_rows(tenant_id, status, charge_created DESC);
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_charge_list_rows_updated_at
+    BEFORE UPDATE ON charge_list_rows
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,23 @@
+-- Migration to add entity history
+-- This stores an immutable snapshot of charges, subscriptions and disputes
+-- for every provider state change, so their state can be queried as of any
+-- point in time
+
+-- Create entity_versions table
+CREATE TABLE IF NOT EXISTS entity_versions (
+    id BIGSERIAL PRIMARY KEY,
+    entity_type VARCHAR(50) NOT NULL,
+    entity_id VARCHAR(255) NOT NULL,
+    status VARCHAR(50) NOT NULL DEFAULT '',
+    state JSONB NOT NULL,
+    event_id VARCHAR(255) NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    valid_from TIMESTAMP WITH TIME ZONE NOT NULL,
+    recorded_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Versions are immutable and recorded once per event
+CREATE UNIQUE INDEX IF NOT EXISTS idx_entity_versions_event ON entity_versions(entity_type, entity_id, event_id);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_entity_versions_entity_valid_from ON entity_versions(entity_type, entity_id, valid_from);
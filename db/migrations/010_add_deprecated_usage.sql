@@ -0,0 +1,16 @@
+-- Migration to add deprecated API usage tracking
+-- This counts requests to deprecated routes and fields per API key so
+-- callers can be contacted before a surface reaches its sunset date
+
+-- Create deprecated_usage table
+CREATE TABLE IF NOT EXISTS deprecated_usage (
+    notice_id VARCHAR(255) NOT NULL,
+    api_key_id VARCHAR(255) NOT NULL,
+    request_count BIGINT NOT NULL DEFAULT 0,
+    first_seen TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_seen TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (notice_id, api_key_id)
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_deprecated_usage_last_seen ON deprecated_usage(notice_id, last_seen DESC);
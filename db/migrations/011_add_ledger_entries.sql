@@ -0,0 +1,21 @@
+-- Migration to add the internal ledger
+-- Each entry moves an amount from a credit account to a debit account, so
+-- every posting is balanced by construction
+
+-- Create ledger_entries table
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id VARCHAR(255) PRIMARY KEY,
+    debit_account VARCHAR(255) NOT NULL,
+    credit_account VARCHAR(255) NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) NOT NULL,
+    reference_type VARCHAR(50) NOT NULL,
+    reference_id VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes for better performance
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_entries_reference ON ledger_entries(reference_type, reference_id, debit_account, credit_account);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_debit_account ON ledger_entries(debit_account, created_at);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_credit_account ON ledger_entries(credit_account, created_at);
@@ -0,0 +1,44 @@
+-- Migration to add automatic refunds of unclaimed funds
+-- Unclaimed balances track customer cash balance funds (e.g. unmatched or
+-- late bank transfers) that were never applied to a payment. Funds left
+-- unclaimed past the configured window are refunded automatically unless
+-- the customer is on the exclusion list.
+
+-- Create unclaimed_balances table
+CREATE TABLE IF NOT EXISTS unclaimed_balances (
+    customer_id VARCHAR(255) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    amount BIGINT NOT NULL DEFAULT 0,
+    funded_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (customer_id, currency)
+);
+
+-- Create auto_refunds table
+CREATE TABLE IF NOT EXISTS auto_refunds (
+    id VARCHAR(255) PRIMARY KEY,
+    customer_id VARCHAR(255) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    amount BIGINT NOT NULL,
+    refund_id VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(50) NOT NULL,
+    failure_reason TEXT NOT NULL DEFAULT '',
+    funded_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create auto_refund_exclusions table
+CREATE TABLE IF NOT EXISTS auto_refund_exclusions (
+    customer_id VARCHAR(255) PRIMARY KEY,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_unclaimed_balances_funded_at ON unclaimed_balances(funded_at) WHERE amount > 0;
+CREATE INDEX IF NOT EXISTS idx_auto_refunds_customer_id ON auto_refunds(customer_id, created_at DESC);
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_unclaimed_balances_updated_at BEFORE UPDATE ON unclaimed_balances
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
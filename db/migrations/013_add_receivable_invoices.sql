@@ -0,0 +1,38 @@
+-- Migration to add receivable invoice tracking
+-- Invoices sent with payment terms (send_invoice collection) are tracked
+-- until paid so overdue invoices can be listed and reminders sent at
+-- configurable offsets from the due date
+
+-- Create receivable_invoices table
+CREATE TABLE IF NOT EXISTS receivable_invoices (
+    invoice_id VARCHAR(255) PRIMARY KEY,
+    customer_id VARCHAR(255) NOT NULL,
+    subscription_id VARCHAR(255) NOT NULL DEFAULT '',
+    number VARCHAR(255) NOT NULL DEFAULT '',
+    amount_due BIGINT NOT NULL,
+    amount_remaining BIGINT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    due_date TIMESTAMP WITH TIME ZONE NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    overdue_at TIMESTAMP WITH TIME ZONE,
+    paid_at TIMESTAMP WITH TIME ZONE,
+    paid_reference VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create invoice_reminders table
+CREATE TABLE IF NOT EXISTS invoice_reminders (
+    invoice_id VARCHAR(255) NOT NULL REFERENCES receivable_invoices(invoice_id) ON DELETE CASCADE,
+    offset_days INTEGER NOT NULL,
+    sent_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (invoice_id, offset_days)
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_receivable_invoices_status_due ON receivable_invoices(status, due_date);
+CREATE INDEX IF NOT EXISTS idx_receivable_invoices_customer_id ON receivable_invoices(customer_id);
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_receivable_invoices_updated_at BEFORE UPDATE ON receivable_invoices
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
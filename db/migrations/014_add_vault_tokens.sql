@@ -0,0 +1,25 @@
+-- Migration to add provider-agnostic payment method tokens
+-- Vault tokens (pmt_...) are the identifiers handed to callers. Each maps to
+-- the payment method token of whichever provider currently holds the card, so
+-- a payment method can be routed or migrated to another provider without
+-- invalidating references stored in client systems.
+
+-- Create vault_tokens table
+CREATE TABLE IF NOT EXISTS vault_tokens (
+    id VARCHAR(255) PRIMARY KEY,
+    provider VARCHAR(50) NOT NULL,
+    provider_token VARCHAR(255) NOT NULL,
+    customer_id VARCHAR(255) NOT NULL DEFAULT '',
+    type VARCHAR(50) NOT NULL DEFAULT '',
+    fingerprint VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (provider, provider_token)
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_vault_tokens_customer_id ON vault_tokens(customer_id);
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_vault_tokens_updated_at BEFORE UPDATE ON vault_tokens
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,18 @@
+-- Migration to add currency-based provider routing
+-- Each charge records the provider it was routed to and the currency that
+-- provider settles in, so volume can be reported per provider and
+-- consolidated in the base reporting currency.
+
+-- Create routed_charges table
+CREATE TABLE IF NOT EXISTS routed_charges (
+    charge_id VARCHAR(255) PRIMARY KEY,
+    provider VARCHAR(50) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    amount BIGINT NOT NULL,
+    settlement_currency VARCHAR(3) NOT NULL,
+    reason VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_routed_charges_created_at ON routed_charges(created_at);
@@ -0,0 +1,41 @@
+-- Migration to add disputes
+-- Disputes are kept with every field the provider reports, including staged
+-- evidence, evidence deadlines and network reason codes. Rows are synced
+-- from dispute webhooks; synced_at guards against out-of-order events.
+
+-- Create disputes table
+CREATE TABLE IF NOT EXISTS disputes (
+    id VARCHAR(255) PRIMARY KEY,
+    charge_id VARCHAR(255) NOT NULL,
+    payment_intent_id VARCHAR(255) NOT NULL DEFAULT '',
+    amount BIGINT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    reason VARCHAR(50) NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    network_reason_code VARCHAR(50) NOT NULL DEFAULT '',
+    is_charge_refundable BOOLEAN NOT NULL DEFAULT FALSE,
+    evidence JSONB NOT NULL,
+    evidence_due_by TIMESTAMP WITH TIME ZONE,
+    has_evidence BOOLEAN NOT NULL DEFAULT FALSE,
+    past_due BOOLEAN NOT NULL DEFAULT FALSE,
+    submission_count INTEGER NOT NULL DEFAULT 0,
+    payment_method_type VARCHAR(50) NOT NULL DEFAULT '',
+    card_brand VARCHAR(50) NOT NULL DEFAULT '',
+    card_network_reason_code VARCHAR(50) NOT NULL DEFAULT '',
+    balance_transactions JSONB NOT NULL,
+    livemode BOOLEAN NOT NULL DEFAULT FALSE,
+    metadata JSONB NOT NULL,
+    disputed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    synced_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_disputes_charge_id ON disputes(charge_id);
+CREATE INDEX IF NOT EXISTS idx_disputes_status ON disputes(status);
+CREATE INDEX IF NOT EXISTS idx_disputes_evidence_due_by ON disputes(evidence_due_by);
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_disputes_updated_at BEFORE UPDATE ON disputes
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,20 @@
+-- Migration to add ephemeral keys
+-- Ephemeral keys are short-lived, scope-limited credentials issued for one
+-- customer so frontend clients can manage that customer's payment methods
+-- directly. Only a SHA-256 hash of the secret is stored.
+
+-- Create ephemeral_keys table
+CREATE TABLE IF NOT EXISTS ephemeral_keys (
+    id VARCHAR(255) PRIMARY KEY,
+    customer_id VARCHAR(255) NOT NULL,
+    secret_hash VARCHAR(64) NOT NULL UNIQUE,
+    scopes JSONB NOT NULL,
+    issued_by VARCHAR(255) NOT NULL DEFAULT '',
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_ephemeral_keys_customer_id ON ephemeral_keys(customer_id);
+CREATE INDEX IF NOT EXISTS idx_ephemeral_keys_expires_at ON ephemeral_keys(expires_at);
@@ -0,0 +1,37 @@
+-- Migration to add per-tenant processing volume budgets
+-- Each tenant may have a monthly budget in the base reporting currency with
+-- alert thresholds and an optional hard stop. Spend is accumulated per
+-- calendar month (UTC), and each threshold alerts at most once per month.
+
+-- Create tenant_budgets table
+CREATE TABLE IF NOT EXISTS tenant_budgets (
+    tenant_id VARCHAR(255) PRIMARY KEY,
+    monthly_limit BIGINT NOT NULL,
+    thresholds JSONB NOT NULL,
+    hard_stop BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_by VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create tenant_spend table
+CREATE TABLE IF NOT EXISTS tenant_spend (
+    tenant_id VARCHAR(255) NOT NULL,
+    period VARCHAR(7) NOT NULL,
+    amount BIGINT NOT NULL DEFAULT 0,
+    charge_count BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (tenant_id, period)
+);
+
+-- Create tenant_budget_alerts table
+CREATE TABLE IF NOT EXISTS tenant_budget_alerts (
+    tenant_id VARCHAR(255) NOT NULL,
+    period VARCHAR(7) NOT NULL,
+    threshold INTEGER NOT NULL,
+    alerted_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, period, threshold)
+);
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_tenant_budgets_updated_at BEFORE UPDATE ON tenant_budgets
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,24 @@
+-- Migration to add customer identities for duplicate detection and email verification
+-- Each customer created through the API is recorded with its tenant, its
+-- normalized email and its name, so a later creation for the same person in
+-- the same tenant can be rejected with a reference to the existing customer.
+
+-- Create customer_identities table
+CREATE TABLE IF NOT EXISTS customer_identities (
+    customer_id VARCHAR(255) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    normalized_email VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    email_verified_at TIMESTAMP WITH TIME ZONE,
+    verification_token_hash VARCHAR(64) NOT NULL DEFAULT '',
+    verification_expires_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_customer_identities_tenant_email ON customer_identities(tenant_id, normalized_email);
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_customer_identities_updated_at BEFORE UPDATE ON customer_identities
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
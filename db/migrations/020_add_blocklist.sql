@@ -0,0 +1,25 @@
+-- Migration to add the internal blocklist
+-- Blocked emails and card fingerprints are mirrored to provider fraud
+-- tooling (Stripe Radar value lists). provider_item_id links an entry to its
+-- mirrored item so removals on either side can be reconciled.
+
+-- Create blocklist_entries table
+CREATE TABLE IF NOT EXISTS blocklist_entries (
+    id VARCHAR(255) PRIMARY KEY,
+    entry_type VARCHAR(50) NOT NULL,
+    value VARCHAR(255) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    source VARCHAR(50) NOT NULL,
+    created_by VARCHAR(255) NOT NULL DEFAULT '',
+    provider_item_id VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(entry_type, value)
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_blocklist_entries_entry_type ON blocklist_entries(entry_type);
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_blocklist_entries_updated_at BEFORE UPDATE ON blocklist_entries
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
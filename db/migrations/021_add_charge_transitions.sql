@@ -0,0 +1,20 @@
+-- Migration to add the charge state machine's transition log
+-- Each row is one validated transition; a charge's current state is the
+-- to_state of its latest transition. The (charge_id, sequence) key rejects
+-- concurrent writers appending from the same state.
+
+-- Create charge_transitions table
+CREATE TABLE IF NOT EXISTS charge_transitions (
+    id VARCHAR(255) PRIMARY KEY,
+    charge_id VARCHAR(255) NOT NULL,
+    sequence INTEGER NOT NULL,
+    from_state VARCHAR(50) NOT NULL DEFAULT '',
+    to_state VARCHAR(50) NOT NULL,
+    source VARCHAR(50) NOT NULL,
+    event_id VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(charge_id, sequence)
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_charge_transitions_to_state ON charge_transitions(to_state);
@@ -0,0 +1,80 @@
+-- Migration to mirror provider state
+-- Customers, payment methods, charges, refunds, subscriptions, plans and
+-- webhook events are stored on every mutation and provider event, so the
+-- local database is a complete copy of provider state. Rows arrive in event
+-- order rather than dependency order, so foreign keys between mirrored
+-- tables are dropped; synced_at guards against out-of-order events.
+
+-- Provider rows reference each other by ID only
+ALTER TABLE payment_methods DROP CONSTRAINT IF EXISTS payment_methods_customer_id_fkey;
+ALTER TABLE charges DROP CONSTRAINT IF EXISTS charges_customer_id_fkey;
+ALTER TABLE charges DROP CONSTRAINT IF EXISTS charges_payment_method_id_fkey;
+ALTER TABLE refunds DROP CONSTRAINT IF EXISTS refunds_charge_id_fkey;
+
+-- The provider allows several customers with the same email
+ALTER TABLE customers DROP CONSTRAINT IF EXISTS customers_email_key;
+
+-- Track when each mirrored row was last synced
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS synced_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW();
+ALTER TABLE payment_methods ADD COLUMN IF NOT EXISTS synced_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW();
+ALTER TABLE refunds ADD COLUMN IF NOT EXISTS synced_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW();
+ALTER TABLE charges
+    ADD COLUMN IF NOT EXISTS amount_refunded BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS captured BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS refunded BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS disputed BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS invoice_id VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS synced_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW();
+
+-- Keep the full payload of every processed webhook event
+ALTER TABLE webhook_events ADD COLUMN IF NOT EXISTS payload JSONB;
+
+-- Create subscription_plans table
+CREATE TABLE IF NOT EXISTS subscription_plans (
+    id VARCHAR(255) PRIMARY KEY,
+    product_id VARCHAR(255) NOT NULL DEFAULT '',
+    nickname VARCHAR(255) NOT NULL DEFAULT '',
+    amount BIGINT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    billing_interval VARCHAR(20) NOT NULL DEFAULT '',
+    interval_count BIGINT NOT NULL DEFAULT 0,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    metadata JSONB NOT NULL,
+    plan_created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    synced_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create subscriptions table
+CREATE TABLE IF NOT EXISTS subscriptions (
+    id VARCHAR(255) PRIMARY KEY,
+    customer_id VARCHAR(255) NOT NULL,
+    plan_id VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(50) NOT NULL,
+    paused BOOLEAN NOT NULL DEFAULT FALSE,
+    cancel_at_period_end BOOLEAN NOT NULL DEFAULT FALSE,
+    current_period_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    current_period_end TIMESTAMP WITH TIME ZONE NOT NULL,
+    collection_method VARCHAR(50) NOT NULL DEFAULT '',
+    days_until_due BIGINT NOT NULL DEFAULT 0,
+    metadata JSONB NOT NULL,
+    subscription_created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    synced_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_subscription_plans_product_id ON subscription_plans(product_id);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_customer_id ON subscriptions(customer_id);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_plan_id ON subscriptions(plan_id);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_status ON subscriptions(status);
+CREATE INDEX IF NOT EXISTS idx_webhook_events_type ON webhook_events(type);
+
+-- Create triggers to automatically update updated_at
+CREATE TRIGGER update_subscription_plans_updated_at BEFORE UPDATE ON subscription_plans
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_subscriptions_updated_at BEFORE UPDATE ON subscriptions
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
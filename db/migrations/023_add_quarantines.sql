@@ -0,0 +1,61 @@
+-- Migration to add API key and tenant quarantines
+-- A quarantined API key or tenant can still read, but its mutations are held
+-- until an administrator releases them, and all of its activity is flagged.
+-- At most one quarantine per subject is active (not yet lifted) at a time.
+
+-- Create quarantines table
+CREATE TABLE IF NOT EXISTS quarantines (
+    id VARCHAR(255) PRIMARY KEY,
+    subject_type VARCHAR(50) NOT NULL,
+    subject_id VARCHAR(255) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_by VARCHAR(255) NOT NULL DEFAULT '',
+    lifted_by VARCHAR(255),
+    lifted_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create quarantine_activity table
+CREATE TABLE IF NOT EXISTS quarantine_activity (
+    id VARCHAR(255) PRIMARY KEY,
+    quarantine_id VARCHAR(255) NOT NULL REFERENCES quarantines(id),
+    tenant_id VARCHAR(255) NOT NULL,
+    api_key_id VARCHAR(255) NOT NULL,
+    method VARCHAR(10) NOT NULL,
+    path TEXT NOT NULL,
+    held_mutation_id VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create held_mutations table
+CREATE TABLE IF NOT EXISTS held_mutations (
+    id VARCHAR(255) PRIMARY KEY,
+    quarantine_id VARCHAR(255) NOT NULL REFERENCES quarantines(id),
+    tenant_id VARCHAR(255) NOT NULL,
+    api_key_id VARCHAR(255) NOT NULL,
+    operator_id VARCHAR(255) NOT NULL,
+    method VARCHAR(10) NOT NULL,
+    path TEXT NOT NULL,
+    content_type VARCHAR(255) NOT NULL DEFAULT '',
+    body TEXT NOT NULL DEFAULT '',
+    status VARCHAR(50) NOT NULL,
+    decided_by VARCHAR(255),
+    decided_at TIMESTAMP WITH TIME ZONE,
+    response_status INTEGER NOT NULL DEFAULT 0,
+    response_body TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes for better performance
+CREATE UNIQUE INDEX IF NOT EXISTS idx_quarantines_active_subject ON quarantines(subject_type, subject_id) WHERE lifted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_quarantine_activity_quarantine_created ON quarantine_activity(quarantine_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_held_mutations_status_created ON held_mutations(status, created_at);
+
+-- Create triggers to automatically update updated_at
+CREATE TRIGGER update_quarantines_updated_at BEFORE UPDATE ON quarantines
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_held_mutations_updated_at BEFORE UPDATE ON held_mutations
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
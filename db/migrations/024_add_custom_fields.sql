@@ -0,0 +1,17 @@
+-- Migration to add custom field definitions
+-- This stores the custom fields (e.g. PO number, cost center) each tenant
+-- collects on charges and invoiced subscriptions
+
+-- Create custom_field_definitions table
+CREATE TABLE IF NOT EXISTS custom_field_definitions (
+    tenant_id VARCHAR(255) PRIMARY KEY,
+    fields JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_custom_field_definitions_updated_at
+    BEFORE UPDATE ON custom_field_definitions
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
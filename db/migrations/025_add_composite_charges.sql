@@ -0,0 +1,66 @@
+-- Migration to add composite charges
+-- A composite charge groups the charges (legs) that paid one order across
+-- several payment methods. Refunds of a composite charge are split across
+-- its legs, and each leg's refund is tracked until it settles.
+
+-- Create composite_charges table
+CREATE TABLE IF NOT EXISTS composite_charges (
+    id VARCHAR(255) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    amount BIGINT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    refund_strategy VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create composite_charge_legs table. A charge can be a leg of one composite charge only.
+CREATE TABLE IF NOT EXISTS composite_charge_legs (
+    composite_charge_id VARCHAR(255) NOT NULL REFERENCES composite_charges(id) ON DELETE CASCADE,
+    charge_id VARCHAR(255) NOT NULL UNIQUE,
+    position INTEGER NOT NULL,
+    priority INTEGER NOT NULL DEFAULT 0,
+    amount BIGINT NOT NULL,
+    PRIMARY KEY (composite_charge_id, charge_id)
+);
+
+-- Create composite_refunds table
+CREATE TABLE IF NOT EXISTS composite_refunds (
+    id VARCHAR(255) PRIMARY KEY,
+    composite_charge_id VARCHAR(255) NOT NULL REFERENCES composite_charges(id) ON DELETE CASCADE,
+    tenant_id VARCHAR(255) NOT NULL,
+    amount BIGINT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create composite_refund_legs table
+CREATE TABLE IF NOT EXISTS composite_refund_legs (
+    composite_refund_id VARCHAR(255) NOT NULL REFERENCES composite_refunds(id) ON DELETE CASCADE,
+    charge_id VARCHAR(255) NOT NULL,
+    position INTEGER NOT NULL,
+    amount BIGINT NOT NULL,
+    refund_id VARCHAR(255) NOT NULL DEFAULT '',
+    approval_id VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(50) NOT NULL,
+    failure_reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (composite_refund_id, charge_id)
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_composite_refunds_composite_charge_id ON composite_refunds(composite_charge_id);
+
+-- Create triggers to automatically update updated_at
+CREATE TRIGGER update_composite_charges_updated_at BEFORE UPDATE ON composite_charges
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_composite_refunds_updated_at BEFORE UPDATE ON composite_refunds
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_composite_refund_legs_updated_at BEFORE UPDATE ON composite_refund_legs
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
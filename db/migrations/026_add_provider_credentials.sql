@@ -0,0 +1,39 @@
+-- Migration to add per-tenant provider credentials
+-- Each tenant stores its own provider keys (e.g. a Stripe secret key or
+-- Paddle API key). Fields are encrypted together; only masked values are kept
+-- in plain text for display. Every change is recorded in the audit table.
+
+-- Create provider_credentials table
+CREATE TABLE IF NOT EXISTS provider_credentials (
+    tenant_id VARCHAR(255) NOT NULL,
+    provider VARCHAR(50) NOT NULL,
+    ciphertext BYTEA NOT NULL,
+    masked_fields JSONB NOT NULL,
+    version INTEGER NOT NULL DEFAULT 1,
+    verified_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, provider)
+);
+
+-- Create provider_credential_audit table
+CREATE TABLE IF NOT EXISTS provider_credential_audit (
+    id VARCHAR(255) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    provider VARCHAR(50) NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    version INTEGER NOT NULL,
+    changed_fields JSONB NOT NULL,
+    api_key_id VARCHAR(255) NOT NULL DEFAULT '',
+    operator_id VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_provider_credential_audit_tenant_provider ON provider_credential_audit(tenant_id, provider, created_at DESC);
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_provider_credentials_updated_at
+    BEFORE UPDATE ON provider_credentials
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
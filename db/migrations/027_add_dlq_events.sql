@@ -0,0 +1,32 @@
+-- Migration to add the durable dead-letter queue
+-- Webhook events and Kafka commands that fail processing are kept here and
+-- retried with exponential backoff until they succeed or run out of
+-- attempts, so nothing is lost when the service restarts. Operators can
+-- list, retry and purge entries through the admin server.
+
+-- Create dlq_events table
+CREATE TABLE IF NOT EXISTS dlq_events (
+    id VARCHAR(255) PRIMARY KEY,
+    source VARCHAR(50) NOT NULL,
+    dedupe_key VARCHAR(255) NOT NULL,
+    payload BYTEA NOT NULL,
+    headers JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR(50) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMP WITH TIME ZONE,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (source, dedupe_key)
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_dlq_events_due ON dlq_events(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_dlq_events_status ON dlq_events(status, created_at);
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_dlq_events_updated_at
+    BEFORE UPDATE ON dlq_events
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,16 @@
+-- Migration to add external references for customer upserts
+-- Callers upsert customers keyed by their own user ID. The unique key makes
+-- the reference the arbiter when concurrent upserts race to create the same
+-- customer: only one mapping is stored and the others update it instead.
+
+-- Create customer_references table
+CREATE TABLE IF NOT EXISTS customer_references (
+    tenant_id VARCHAR(255) NOT NULL,
+    external_reference VARCHAR(255) NOT NULL,
+    customer_id VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, external_reference)
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_customer_references_customer ON customer_references(customer_id);
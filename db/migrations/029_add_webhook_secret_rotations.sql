@@ -0,0 +1,31 @@
+-- Migration to add webhook secret rotations
+-- Rotating the webhook signing secret creates a second provider endpoint
+-- with a new secret. Both secrets are accepted for a grace period, and the
+-- old endpoint is retired once deliveries signed with the new secret have
+-- succeeded. Secrets are stored encrypted.
+
+-- Create webhook_secret_rotations table
+CREATE TABLE IF NOT EXISTS webhook_secret_rotations (
+    id VARCHAR(255) PRIMARY KEY,
+    status VARCHAR(50) NOT NULL DEFAULT 'pending',
+    old_endpoint_id VARCHAR(255) NOT NULL,
+    new_endpoint_id VARCHAR(255) NOT NULL,
+    new_secret BYTEA NOT NULL,
+    deliveries INTEGER NOT NULL DEFAULT 0,
+    confirmed_at TIMESTAMP WITH TIME ZONE,
+    grace_ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    cancelled_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Only one rotation can be in progress at a time
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_secret_rotations_pending ON webhook_secret_rotations(status) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_secret_rotations_completed ON webhook_secret_rotations(completed_at) WHERE status = 'completed';
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_webhook_secret_rotations_updated_at
+    BEFORE UPDATE ON webhook_secret_rotations
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
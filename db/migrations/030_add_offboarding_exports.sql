@@ -0,0 +1,43 @@
+-- Migration to add offboarding exports
+-- A merchant leaving the platform is handed an archive of its customers,
+-- payment methods, subscriptions and transaction history, encrypted to a key
+-- it provides. Card numbers are never stored here, so they move to the new
+-- PSP through a PAN migration requested from the provider instead.
+
+-- Create offboarding_exports table
+CREATE TABLE IF NOT EXISTS offboarding_exports (
+    id VARCHAR(255) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    status VARCHAR(50) NOT NULL DEFAULT 'pending',
+    recipient_public_key TEXT NOT NULL,
+    manifest JSONB,
+    archive_sha256 VARCHAR(64) NOT NULL DEFAULT '',
+    archive_size BIGINT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    pan_migration_status VARCHAR(50) NOT NULL DEFAULT '',
+    pan_migration_destination VARCHAR(255) NOT NULL DEFAULT '',
+    pan_migration_reference VARCHAR(255) NOT NULL DEFAULT '',
+    pan_migration_requested_at TIMESTAMP WITH TIME ZONE,
+    started_at TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Archives are kept apart so listing exports does not load them
+CREATE TABLE IF NOT EXISTS offboarding_archives (
+    export_id VARCHAR(255) PRIMARY KEY REFERENCES offboarding_exports(id) ON DELETE CASCADE,
+    archive BYTEA NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_offboarding_exports_tenant_created ON offboarding_exports(tenant_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_offboarding_exports_status ON offboarding_exports(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_customer_identities_tenant_created ON customer_identities(tenant_id, created_at);
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_offboarding_exports_updated_at
+    BEFORE UPDATE ON offboarding_exports
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,37 @@
+-- Migration to add invoices
+-- Every invoice created through the API or reported by invoice webhooks is
+-- kept, whatever its collection method; receivable_invoices only tracks the
+-- ones sent with payment terms. synced_at guards against out-of-order events.
+
+-- Create invoices table
+CREATE TABLE IF NOT EXISTS invoices (
+    id VARCHAR(255) PRIMARY KEY,
+    customer_id VARCHAR(255) NOT NULL,
+    subscription_id VARCHAR(255) NOT NULL DEFAULT '',
+    number VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(50) NOT NULL,
+    collection_method VARCHAR(50) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    amount_due BIGINT NOT NULL,
+    amount_paid BIGINT NOT NULL,
+    amount_remaining BIGINT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    due_date TIMESTAMP WITH TIME ZONE,
+    paid_out_of_band BOOLEAN NOT NULL DEFAULT FALSE,
+    hosted_invoice_url TEXT NOT NULL DEFAULT '',
+    invoice_pdf TEXT NOT NULL DEFAULT '',
+    custom_fields JSONB NOT NULL,
+    metadata JSONB NOT NULL,
+    invoiced_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    synced_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_invoices_customer_invoiced ON invoices(customer_id, invoiced_at DESC);
+CREATE INDEX IF NOT EXISTS idx_invoices_status ON invoices(status);
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_invoices_updated_at BEFORE UPDATE ON invoices
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,45 @@
+-- Migration to add payment links
+-- Payment links are shareable checkout URLs for a fixed amount of one
+-- product. Stripe deactivates a link after max_uses completed checkouts;
+-- expires_at is enforced locally. Each paid checkout through a link is
+-- recorded once as a conversion.
+
+-- Create payment_links table
+CREATE TABLE IF NOT EXISTS payment_links (
+    id VARCHAR(255) PRIMARY KEY,
+    url TEXT NOT NULL,
+    amount BIGINT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    product_name VARCHAR(250) NOT NULL,
+    price_id VARCHAR(255) NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    max_uses BIGINT NOT NULL DEFAULT 0,
+    conversions BIGINT NOT NULL DEFAULT 0,
+    amount_collected BIGINT NOT NULL DEFAULT 0,
+    metadata JSONB NOT NULL,
+    deactivated_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create payment_link_conversions table
+CREATE TABLE IF NOT EXISTS payment_link_conversions (
+    session_id VARCHAR(255) PRIMARY KEY,
+    payment_link_id VARCHAR(255) NOT NULL REFERENCES payment_links(id),
+    amount_total BIGINT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    customer_id VARCHAR(255) NOT NULL DEFAULT '',
+    payment_intent_id VARCHAR(255) NOT NULL DEFAULT '',
+    completed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_payment_links_active_created ON payment_links(active, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_payment_links_expiring ON payment_links(expires_at) WHERE active AND expires_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_payment_link_conversions_link ON payment_link_conversions(payment_link_id, completed_at DESC);
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_payment_links_updated_at BEFORE UPDATE ON payment_links
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
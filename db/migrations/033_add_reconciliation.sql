@@ -0,0 +1,39 @@
+-- Migration to add reconciliation runs
+-- Each run matches the provider's balance transactions and payouts for a
+-- period against the locally stored charges and refunds. Mismatches are kept
+-- with the run that found them.
+
+-- Create reconciliation_runs table
+CREATE TABLE IF NOT EXISTS reconciliation_runs (
+    id VARCHAR(255) PRIMARY KEY,
+    status VARCHAR(20) NOT NULL,
+    period_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    period_end TIMESTAMP WITH TIME ZONE NOT NULL,
+    transactions_checked BIGINT NOT NULL DEFAULT 0,
+    payouts_checked BIGINT NOT NULL DEFAULT 0,
+    matched BIGINT NOT NULL DEFAULT 0,
+    mismatches BIGINT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    completed_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Create reconciliation_mismatches table
+CREATE TABLE IF NOT EXISTS reconciliation_mismatches (
+    id VARCHAR(255) PRIMARY KEY,
+    run_id VARCHAR(255) NOT NULL REFERENCES reconciliation_runs(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,
+    object_type VARCHAR(20) NOT NULL,
+    object_id VARCHAR(255) NOT NULL,
+    balance_transaction_id VARCHAR(255) NOT NULL DEFAULT '',
+    provider_amount BIGINT NOT NULL DEFAULT 0,
+    local_amount BIGINT NOT NULL DEFAULT 0,
+    currency VARCHAR(3) NOT NULL DEFAULT '',
+    detail TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_reconciliation_runs_started ON reconciliation_runs(started_at DESC);
+CREATE INDEX IF NOT EXISTS idx_reconciliation_mismatches_run ON reconciliation_mismatches(run_id, kind);
+CREATE INDEX IF NOT EXISTS idx_charges_captured_created ON charges(created_at) WHERE captured;
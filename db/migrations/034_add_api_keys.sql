@@ -0,0 +1,22 @@
+-- Migration to add API keys
+-- API keys authenticate server-to-server calls to the API. Each key is
+-- granted scopes and may be bound to a tenant. Only a SHA-256 hash of the
+-- secret is stored; rotated keys expire after a grace period.
+
+-- Create api_keys table
+CREATE TABLE IF NOT EXISTS api_keys (
+    id VARCHAR(255) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    secret_hash VARCHAR(64) NOT NULL UNIQUE,
+    secret_hint VARCHAR(32) NOT NULL,
+    scopes JSONB NOT NULL,
+    tenant_id VARCHAR(255) NOT NULL DEFAULT '',
+    created_by VARCHAR(255) NOT NULL DEFAULT '',
+    rotated_from VARCHAR(255) NOT NULL DEFAULT '',
+    expires_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_api_keys_tenant_id ON api_keys(tenant_id);
@@ -0,0 +1,36 @@
+-- Migration to add tenant isolation
+-- Each tenant is a merchant with its own provider accounts. Its
+-- configuration is kept in tenant_configs; its provider keys are the
+-- per-tenant provider credentials. Records mirrored from a provider are
+-- tagged with the tenant they were created for, and existing records belong
+-- to the default tenant, which uses the platform's own credentials.
+
+-- Create tenant_configs table
+CREATE TABLE IF NOT EXISTS tenant_configs (
+    tenant_id VARCHAR(255) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(50) NOT NULL DEFAULT 'active',
+    default_provider VARCHAR(50) NOT NULL DEFAULT 'stripe',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Tag records with their tenant
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(255) NOT NULL DEFAULT 'default';
+ALTER TABLE payment_methods ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(255) NOT NULL DEFAULT 'default';
+ALTER TABLE charges ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(255) NOT NULL DEFAULT 'default';
+ALTER TABLE refunds ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(255) NOT NULL DEFAULT 'default';
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(255) NOT NULL DEFAULT 'default';
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_customers_tenant_id ON customers(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_payment_methods_tenant_id ON payment_methods(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_charges_tenant_id ON charges(tenant_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_refunds_tenant_id ON refunds(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_tenant_id ON subscriptions(tenant_id);
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_tenant_configs_updated_at
+    BEFORE UPDATE ON tenant_configs
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,33 @@
+-- Migration to add smart routing rule overrides
+-- Smart routing picks the cheapest or highest-authorization-rate provider for
+-- a gateway charge. Rules override that choice for charges matching a
+-- currency, card BIN country and amount range: they may switch the strategy,
+-- limit the candidate providers or pin a single provider. The enabled rule
+-- with the lowest priority that matches a charge applies.
+
+-- Create smart_routing_rules table
+CREATE TABLE IF NOT EXISTS smart_routing_rules (
+    id VARCHAR(255) PRIMARY KEY,
+    currency VARCHAR(3) NOT NULL DEFAULT '',
+    bin_country VARCHAR(2) NOT NULL DEFAULT '',
+    min_amount BIGINT NOT NULL DEFAULT 0,
+    max_amount BIGINT NOT NULL DEFAULT 0,
+    strategy VARCHAR(50) NOT NULL DEFAULT '',
+    providers JSONB NOT NULL DEFAULT '[]',
+    pinned_provider VARCHAR(50) NOT NULL DEFAULT '',
+    priority INTEGER NOT NULL DEFAULT 100,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    description TEXT NOT NULL DEFAULT '',
+    updated_by VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_smart_routing_rules_priority ON smart_routing_rules(priority, created_at);
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_smart_routing_rules_updated_at
+    BEFORE UPDATE ON smart_routing_rules
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,76 @@
+-- Migration to add fraud screening
+-- Charges are screened before they are created. Each screening is kept for
+-- velocity counts and amount baselines. Allow and deny lists match
+-- customers, card fingerprints and IP addresses. Stripe Radar scores of
+-- past charges are kept per customer and card. Charges screened for review
+-- wait in fraud_reviews until a reviewer approves or rejects them.
+
+-- Create fraud_screenings table
+CREATE TABLE IF NOT EXISTS fraud_screenings (
+    id VARCHAR(255) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    customer_id VARCHAR(255) NOT NULL DEFAULT '',
+    card_fingerprint VARCHAR(255) NOT NULL DEFAULT '',
+    ip_address VARCHAR(64) NOT NULL DEFAULT '',
+    amount BIGINT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    decision VARCHAR(50) NOT NULL,
+    findings JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create fraud_list_entries table
+CREATE TABLE IF NOT EXISTS fraud_list_entries (
+    id VARCHAR(255) PRIMARY KEY,
+    list VARCHAR(50) NOT NULL,
+    entry_type VARCHAR(50) NOT NULL,
+    value VARCHAR(255) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_by VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (list, entry_type, value)
+);
+
+-- Create fraud_radar_scores table
+CREATE TABLE IF NOT EXISTS fraud_radar_scores (
+    charge_id VARCHAR(255) PRIMARY KEY,
+    customer_id VARCHAR(255) NOT NULL DEFAULT '',
+    card_fingerprint VARCHAR(255) NOT NULL DEFAULT '',
+    risk_score INTEGER NOT NULL,
+    risk_level VARCHAR(50) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create fraud_reviews table
+CREATE TABLE IF NOT EXISTS fraud_reviews (
+    id VARCHAR(255) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    screening_id VARCHAR(255) NOT NULL,
+    customer_id VARCHAR(255) NOT NULL DEFAULT '',
+    amount BIGINT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    request JSONB NOT NULL,
+    findings JSONB NOT NULL DEFAULT '[]',
+    status VARCHAR(50) NOT NULL DEFAULT 'pending',
+    decided_by VARCHAR(255) NOT NULL DEFAULT '',
+    charge_id VARCHAR(255) NOT NULL DEFAULT '',
+    failure_reason TEXT NOT NULL DEFAULT '',
+    decided_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_fraud_screenings_customer ON fraud_screenings(customer_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_fraud_screenings_card ON fraud_screenings(card_fingerprint, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_fraud_screenings_ip ON fraud_screenings(ip_address, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_fraud_list_entries_value ON fraud_list_entries(entry_type, value);
+CREATE INDEX IF NOT EXISTS idx_fraud_radar_scores_customer ON fraud_radar_scores(customer_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_fraud_radar_scores_card ON fraud_radar_scores(card_fingerprint, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_fraud_reviews_status ON fraud_reviews(tenant_id, status, created_at DESC);
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_fraud_reviews_updated_at
+    BEFORE UPDATE ON fraud_reviews
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,46 @@
+-- Migration to add dispute evidence
+-- Evidence is staged here before it is submitted to the provider. Text
+-- fields and the provider file IDs of attached files are kept under Stripe's
+-- evidence field names. Uploaded files are recorded per dispute, and each
+-- evidence deadline is reminded about once.
+
+-- Create dispute_evidence table
+CREATE TABLE IF NOT EXISTS dispute_evidence (
+    dispute_id VARCHAR(255) PRIMARY KEY,
+    fields JSONB NOT NULL DEFAULT '{}',
+    updated_by VARCHAR(255) NOT NULL DEFAULT '',
+    submitted_by VARCHAR(255) NOT NULL DEFAULT '',
+    submitted_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create dispute_evidence_files table
+CREATE TABLE IF NOT EXISTS dispute_evidence_files (
+    id VARCHAR(255) PRIMARY KEY,
+    dispute_id VARCHAR(255) NOT NULL,
+    provider_file_id VARCHAR(255) NOT NULL,
+    field VARCHAR(100) NOT NULL DEFAULT '',
+    filename VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL,
+    uploaded_by VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create dispute_evidence_reminders table
+CREATE TABLE IF NOT EXISTS dispute_evidence_reminders (
+    dispute_id VARCHAR(255) NOT NULL,
+    due_by TIMESTAMP WITH TIME ZONE NOT NULL,
+    sent_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (dispute_id, due_by)
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_dispute_evidence_files_dispute_id ON dispute_evidence_files(dispute_id, created_at);
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_dispute_evidence_updated_at
+    BEFORE UPDATE ON dispute_evidence
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
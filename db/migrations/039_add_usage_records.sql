@@ -0,0 +1,34 @@
+-- Migration to add metered usage records
+-- Usage reported for a subscription's metered price is kept locally, so
+-- consumption can be queried mid-cycle, and pushed to the provider as a usage
+-- record. Records that fail to push are retried until they run out of
+-- attempts. An idempotency key makes a report count once per subscription.
+
+-- Create usage_records table
+CREATE TABLE IF NOT EXISTS usage_records (
+    id VARCHAR(255) PRIMARY KEY,
+    subscription_id VARCHAR(255) NOT NULL,
+    subscription_item_id VARCHAR(255) NOT NULL,
+    price_id VARCHAR(255) NOT NULL DEFAULT '',
+    quantity BIGINT NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    recorded_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL DEFAULT '',
+    provider_record_id VARCHAR(255) NOT NULL DEFAULT '',
+    reported_at TIMESTAMP WITH TIME ZONE,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes for better performance
+CREATE UNIQUE INDEX IF NOT EXISTS idx_usage_records_idempotency_key ON usage_records(subscription_id, idempotency_key) WHERE idempotency_key <> '';
+CREATE INDEX IF NOT EXISTS idx_usage_records_subscription ON usage_records(subscription_id, recorded_at);
+CREATE INDEX IF NOT EXISTS idx_usage_records_unreported ON usage_records(created_at) WHERE reported_at IS NULL;
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_usage_records_updated_at
+    BEFORE UPDATE ON usage_records
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,34 @@
+-- Migration to add dunning cases
+-- A dunning case opens when a subscription invoice charged automatically
+-- fails to pay. Payment is retried on a schedule while the case moves from
+-- grace to past_due, suspended and finally canceled. Payment of the invoice
+-- at any point recovers the case; voiding it closes the case.
+
+-- Create dunning_cases table
+CREATE TABLE IF NOT EXISTS dunning_cases (
+    id VARCHAR(255) PRIMARY KEY,
+    invoice_id VARCHAR(255) NOT NULL UNIQUE,
+    subscription_id VARCHAR(255) NOT NULL,
+    customer_id VARCHAR(255) NOT NULL,
+    amount_due BIGINT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    state VARCHAR(20) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_retry_at TIMESTAMP WITH TIME ZONE,
+    last_error TEXT NOT NULL DEFAULT '',
+    failed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_dunning_cases_open ON dunning_cases(failed_at) WHERE resolved_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_dunning_cases_subscription ON dunning_cases(subscription_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_dunning_cases_customer ON dunning_cases(customer_id, created_at DESC);
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_dunning_cases_updated_at
+    BEFORE UPDATE ON dunning_cases
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
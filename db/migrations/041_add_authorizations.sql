@@ -0,0 +1,32 @@
+-- Migration to add authorizations
+-- An authorization is a charge created without capture. It stays open while
+-- it holds the customer's funds and can be captured, in several parts where
+-- the provider allows it, until it expires at the provider. Open
+-- authorizations close when captured, voided or expired.
+
+-- Create authorizations table
+CREATE TABLE IF NOT EXISTS authorizations (
+    charge_id VARCHAR(255) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    provider VARCHAR(50) NOT NULL,
+    amount BIGINT NOT NULL,
+    amount_captured BIGINT NOT NULL DEFAULT 0,
+    currency VARCHAR(3) NOT NULL,
+    captures INTEGER NOT NULL DEFAULT 0,
+    multi_capture BOOLEAN NOT NULL DEFAULT FALSE,
+    status VARCHAR(20) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    closed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_authorizations_open ON authorizations(expires_at) WHERE status = 'open';
+CREATE INDEX IF NOT EXISTS idx_authorizations_tenant ON authorizations(tenant_id, created_at DESC);
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_authorizations_updated_at
+    BEFORE UPDATE ON authorizations
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,42 @@
+-- Migration to add refund batches
+-- Batches of refunds, and single refunds scheduled for later, are issued by
+-- workers. Each item's result is recorded as it is issued so progress can be
+-- followed while the batch runs.
+
+-- Create refund_batches table
+CREATE TABLE IF NOT EXISTS refund_batches (
+    id VARCHAR(255) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    api_key_id VARCHAR(255) NOT NULL DEFAULT '',
+    operator_id VARCHAR(255) NOT NULL DEFAULT '',
+    requests JSONB NOT NULL,
+    status VARCHAR(50) NOT NULL DEFAULT 'pending',
+    scheduled_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    started_at TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create refund_batch_items table
+CREATE TABLE IF NOT EXISTS refund_batch_items (
+    batch_id VARCHAR(255) NOT NULL REFERENCES refund_batches(id) ON DELETE CASCADE,
+    item_index INTEGER NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    amount BIGINT NOT NULL DEFAULT 0,
+    refund_id VARCHAR(255) NOT NULL DEFAULT '',
+    approval_id VARCHAR(255) NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (batch_id, item_index)
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_refund_batches_runnable ON refund_batches(status, scheduled_at);
+CREATE INDEX IF NOT EXISTS idx_refund_batches_tenant_created ON refund_batches(tenant_id, created_at DESC);
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_refund_batches_updated_at
+    BEFORE UPDATE ON refund_batches
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
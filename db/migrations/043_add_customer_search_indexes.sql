@@ -0,0 +1,10 @@
+-- Migration to index customer listing and search
+-- Customers are listed newest first within a tenant and filtered by email,
+-- name prefix, metadata and creation date. Typeahead matches email and name
+-- prefixes. Lowercased columns use text_pattern_ops so prefix LIKE patterns
+-- can scan them.
+
+CREATE INDEX IF NOT EXISTS idx_customers_tenant_created ON customers(tenant_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_customers_tenant_email ON customers(tenant_id, lower(email) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_customers_tenant_name ON customers(tenant_id, lower(name) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_customers_metadata ON customers USING GIN (metadata jsonb_path_ops);
@@ -0,0 +1,13 @@
+-- Migration to index charge search
+-- Charges are listed newest first within a tenant and filtered by status,
+-- currency, customer, amount, card, metadata and creation date. The common
+-- equality filters lead composite indexes so a page reads in order; card
+-- filters look up the charge's payment method by brand and last4.
+
+CREATE INDEX IF NOT EXISTS idx_charges_tenant_created ON charges(tenant_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_charges_tenant_status_created ON charges(tenant_id, status, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_charges_tenant_customer_created ON charges(tenant_id, customer_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_charges_tenant_amount ON charges(tenant_id, amount);
+CREATE INDEX IF NOT EXISTS idx_charges_payment_method_id ON charges(payment_method_id);
+CREATE INDEX IF NOT EXISTS idx_charges_metadata ON charges USING GIN (metadata jsonb_path_ops);
+CREATE INDEX IF NOT EXISTS idx_payment_methods_card_last4 ON payment_methods(card_last4);
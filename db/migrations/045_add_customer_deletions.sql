@@ -0,0 +1,27 @@
+-- Migration to add customer soft deletion and erasure
+-- Deleting a customer records when it was deleted and when its retention
+-- period ends; the customer is only deleted at the provider once it has.
+-- Erasing a customer anonymizes its personal data while its charges and
+-- refunds are kept, and the erasure keeps provider webhooks from mirroring
+-- the data back.
+
+-- Create customer_deletions table
+CREATE TABLE IF NOT EXISTS customer_deletions (
+    customer_id VARCHAR(255) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    deleted_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    purge_after TIMESTAMP WITH TIME ZONE NOT NULL,
+    purged_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create customer_erasures table
+CREATE TABLE IF NOT EXISTS customer_erasures (
+    customer_id VARCHAR(255) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    erased_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_customer_deletions_due ON customer_deletions(purge_after) WHERE purged_at IS NULL;
@@ -0,0 +1,51 @@
+-- Migration to add the audit log
+-- Every mutating API and admin call is recorded with who made it, what it
+-- changed and from where. Entries are numbered and chained: each hash covers
+-- the entry and the previous entry's hash, so an edited, removed or reordered
+-- entry breaks the chain. States are stored as JSON rather than JSONB so
+-- they are read back exactly as they were hashed.
+
+-- Create audit_log table
+CREATE TABLE IF NOT EXISTS audit_log (
+    sequence BIGINT PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL DEFAULT '',
+    actor_type VARCHAR(50) NOT NULL,
+    actor_id VARCHAR(255) NOT NULL DEFAULT '',
+    operator_id VARCHAR(255) NOT NULL DEFAULT '',
+    action VARCHAR(100) NOT NULL,
+    resource_type VARCHAR(100) NOT NULL,
+    resource_id VARCHAR(255) NOT NULL DEFAULT '',
+    method VARCHAR(10) NOT NULL,
+    path TEXT NOT NULL,
+    status INTEGER NOT NULL,
+    before_state JSON,
+    after_state JSON,
+    changes JSON NOT NULL,
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    request_id VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    previous_hash VARCHAR(64) NOT NULL,
+    hash VARCHAR(64) NOT NULL
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_audit_log_tenant ON audit_log(tenant_id, sequence DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_resource ON audit_log(resource_type, resource_id, sequence DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log(actor_id, sequence DESC);
+
+-- Create trigger function rejecting changes to audit entries
+CREATE OR REPLACE FUNCTION reject_audit_log_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ language 'plpgsql';
+
+-- Create triggers keeping the audit log append-only
+CREATE TRIGGER audit_log_append_only
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION reject_audit_log_change();
+
+CREATE TRIGGER audit_log_no_truncate
+    BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION reject_audit_log_change();
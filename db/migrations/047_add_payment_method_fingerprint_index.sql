@@ -0,0 +1,7 @@
+-- Migration to index payment methods by card fingerprint
+-- Cards are checked against the other customers that saved them and the
+-- chargebacks on their charges before they are attached or charged.
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_payment_methods_tenant_fingerprint ON payment_methods(tenant_id, card_fingerprint);
+CREATE INDEX IF NOT EXISTS idx_charges_payment_method_id ON charges(payment_method_id);
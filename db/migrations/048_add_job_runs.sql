@@ -0,0 +1,22 @@
+-- Migration to add background job run history
+-- Each run of a scheduled job records the instance that ran it, how many
+-- attempts it took and how it ended. Runs are exclusive across replicas
+-- through advisory locks, so a run still marked running when the next one
+-- starts was abandoned by an instance that stopped.
+
+-- Create job_runs table
+CREATE TABLE IF NOT EXISTS job_runs (
+    id VARCHAR(255) PRIMARY KEY,
+    job_name VARCHAR(255) NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    triggered_by VARCHAR(50) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    instance VARCHAR(255) NOT NULL DEFAULT '',
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    finished_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_job_runs_job_started ON job_runs(job_name, started_at DESC);
@@ -0,0 +1,21 @@
+-- Migration to add simulator state
+-- The simulator provider keeps the customers, payment methods, charges,
+-- refunds, disputes and subscriptions integrators create against it, so
+-- they outlive restarts and are shared across replicas. Objects are stored
+-- as the gateway returns them, scoped to the tenant that created them.
+
+-- Create simulator_objects table
+CREATE TABLE IF NOT EXISTS simulator_objects (
+    tenant_id VARCHAR(255) NOT NULL,
+    id VARCHAR(255) NOT NULL,
+    kind VARCHAR(50) NOT NULL,
+    parent_id VARCHAR(255) NOT NULL DEFAULT '',
+    data JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, id)
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_simulator_objects_kind ON simulator_objects(tenant_id, kind, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_simulator_objects_parent ON simulator_objects(tenant_id, kind, parent_id, created_at DESC, id DESC);
@@ -0,0 +1,22 @@
+-- Migration to add the event archive
+-- Every event this service emits is kept here, so operators can replay
+-- events by time range, type or resource to a Kafka topic or a tenant's
+-- webhook endpoint. Relayed events keep their provider event ID, so an event
+-- published again is stored once.
+
+-- Create event_archive table
+CREATE TABLE IF NOT EXISTS event_archive (
+    id VARCHAR(255) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    type VARCHAR(255) NOT NULL,
+    subject VARCHAR(255) NOT NULL DEFAULT '',
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    payload JSONB NOT NULL,
+    archived_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_event_archive_occurred_at ON event_archive(occurred_at, id);
+CREATE INDEX IF NOT EXISTS idx_event_archive_type ON event_archive(type, occurred_at);
+CREATE INDEX IF NOT EXISTS idx_event_archive_subject ON event_archive(subject, occurred_at) WHERE subject <> '';
+CREATE INDEX IF NOT EXISTS idx_event_archive_tenant ON event_archive(tenant_id, occurred_at);
@@ -0,0 +1,21 @@
+-- Migration to add receipt settings
+-- Each tenant may have the provider email receipts to its customers, give
+-- its charges a default statement descriptor suffix, and name the business
+-- and support address printed on locally generated receipts.
+
+-- Create receipt_settings table
+CREATE TABLE IF NOT EXISTS receipt_settings (
+    tenant_id VARCHAR(255) PRIMARY KEY,
+    send_receipt_emails BOOLEAN NOT NULL DEFAULT FALSE,
+    descriptor_suffix VARCHAR(22) NOT NULL DEFAULT '',
+    business_name TEXT NOT NULL DEFAULT '',
+    support_email TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_receipt_settings_updated_at
+    BEFORE UPDATE ON receipt_settings
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,52 @@
+-- Migration to add rendered documents
+-- Invoices keep their line items, tax amounts and customer details so PDF
+-- invoices can be rendered from local data. Tenants brand their invoices
+-- and receipts with a template per kind, and every rendered PDF is stored
+-- in object storage, one per source and language.
+
+-- Keep what is printed on invoices
+ALTER TABLE invoices
+    ADD COLUMN IF NOT EXISTS customer_name TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS customer_email TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS subtotal BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS tax BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS total BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS lines JSONB NOT NULL DEFAULT '[]',
+    ADD COLUMN IF NOT EXISTS tax_amounts JSONB NOT NULL DEFAULT '[]';
+
+-- Create document_templates table
+CREATE TABLE IF NOT EXISTS document_templates (
+    tenant_id VARCHAR(255) NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    business_name TEXT NOT NULL DEFAULT '',
+    address TEXT NOT NULL DEFAULT '',
+    tax_id VARCHAR(255) NOT NULL DEFAULT '',
+    accent_color VARCHAR(7) NOT NULL DEFAULT '',
+    footer TEXT NOT NULL DEFAULT '',
+    language VARCHAR(2) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, kind)
+);
+
+-- Create documents table
+CREATE TABLE IF NOT EXISTS documents (
+    id VARCHAR(255) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    source_id VARCHAR(255) NOT NULL,
+    language VARCHAR(2) NOT NULL,
+    object_key TEXT NOT NULL,
+    checksum VARCHAR(64) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (tenant_id, kind, source_id, language)
+);
+
+-- Create triggers to automatically update updated_at
+CREATE TRIGGER update_document_templates_updated_at BEFORE UPDATE ON document_templates
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_documents_updated_at BEFORE UPDATE ON documents
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,20 @@
+-- Migration to add payment method preferences
+-- Each customer has a default payment method, set as the provider
+-- customer's default too, and backups tried in order when it is declined.
+
+-- Create payment_method_preferences table
+CREATE TABLE IF NOT EXISTS payment_method_preferences (
+    tenant_id VARCHAR(255) NOT NULL,
+    customer_id VARCHAR(255) NOT NULL,
+    default_payment_method VARCHAR(255) NOT NULL DEFAULT '',
+    backup_payment_methods JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, customer_id)
+);
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_payment_method_preferences_updated_at
+    BEFORE UPDATE ON payment_method_preferences
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,42 @@
+-- Migration to add customer credit balances
+-- Credits issued to a customer are kept per currency and applied to their
+-- charges and invoices before their payment method is charged. Every
+-- movement of a balance is recorded as a transaction.
+
+-- Create customer_balances table
+CREATE TABLE IF NOT EXISTS customer_balances (
+    tenant_id VARCHAR(255) NOT NULL,
+    customer_id VARCHAR(255) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    amount BIGINT NOT NULL DEFAULT 0 CHECK (amount >= 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, customer_id, currency)
+);
+
+-- Create customer_balance_transactions table
+CREATE TABLE IF NOT EXISTS customer_balance_transactions (
+    id VARCHAR(255) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    customer_id VARCHAR(255) NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    reason VARCHAR(50) NOT NULL DEFAULT '',
+    amount BIGINT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    balance_after BIGINT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    source_id VARCHAR(255) NOT NULL DEFAULT '',
+    provider_transaction_id VARCHAR(255) NOT NULL DEFAULT '',
+    created_by VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_customer_balance_transactions_customer ON customer_balance_transactions(tenant_id, customer_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_customer_balance_transactions_source ON customer_balance_transactions(tenant_id, type, source_id) WHERE source_id <> '';
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_customer_balances_updated_at
+    BEFORE UPDATE ON customer_balances
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,27 @@
+-- Migration to tag the remaining tenant records with their tenant
+-- Disputes, invoices and dunning cases mirrored from a provider take the
+-- tenant of their charge or customer; payment links and vault tokens take
+-- the tenant they were created for. Existing records are backfilled the same
+-- way and otherwise belong to the default tenant.
+
+-- Tag records with their tenant
+ALTER TABLE disputes ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(255) NOT NULL DEFAULT 'default';
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(255) NOT NULL DEFAULT 'default';
+ALTER TABLE dunning_cases ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(255) NOT NULL DEFAULT 'default';
+ALTER TABLE payment_links ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(255) NOT NULL DEFAULT 'default';
+ALTER TABLE vault_tokens ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(255) NOT NULL DEFAULT 'default';
+
+-- Backfill mirrored records from their charge or customer
+UPDATE disputes SET tenant_id = charges.tenant_id
+FROM charges WHERE charges.id = disputes.charge_id;
+UPDATE invoices SET tenant_id = customers.tenant_id
+FROM customers WHERE customers.id = invoices.customer_id;
+UPDATE dunning_cases SET tenant_id = customers.tenant_id
+FROM customers WHERE customers.id = dunning_cases.customer_id;
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_disputes_tenant_id ON disputes(tenant_id, disputed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_invoices_tenant_id ON invoices(tenant_id, invoiced_at DESC);
+CREATE INDEX IF NOT EXISTS idx_dunning_cases_tenant_id ON dunning_cases(tenant_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_payment_links_tenant_id ON payment_links(tenant_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_vault_tokens_tenant_id ON vault_tokens(tenant_id);
@@ -0,0 +1,6 @@
+-- Migration to clear the response bodies logged for webhook deliveries
+-- Attempts now keep only the status an endpoint answered with, so an
+-- endpoint can't be used to read whatever answers at its URL. Bodies logged
+-- before are cleared the same way.
+
+UPDATE webhook_delivery_attempts SET response_body = '' WHERE response_body <> '';
@@ -0,0 +1,16 @@
+-- Migration to add the relay outbox
+-- With RELAY_OUTBOX_ENABLED=true, events relayed from provider webhooks are
+-- stored here with the tenant they were emitted for, and worker instances
+-- publish them in batches. Published events are deleted. Relayed events keep
+-- their provider event ID, so a redelivered webhook is stored once.
+
+-- Create event_outbox table
+CREATE TABLE IF NOT EXISTS event_outbox (
+    id VARCHAR(255) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_event_outbox_created_at ON event_outbox(created_at, id);
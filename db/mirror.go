@@ -0,0 +1,192 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/stripe"
+	"apis/payments/services/tenancy"
+
+	"github.com/sqlc-dev/pqtype"
+)
+
+// UpsertMirroredCustomer stores a provider customer unless a newer version is already stored
+func (r *Repository) UpsertMirroredCustomer(ctx context.Context, customer *stripe.Customer, syncedAt time.Time) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.UpsertMirroredCustomer")
+	defer span.End()
+
+	metadata, err := nullMetadata(customer.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal customer metadata: %w", err)
+	}
+
+	params := sqlc.UpsertMirroredCustomerParams{
+		ID:          customer.ID,
+		Email:       customer.Email,
+		Name:        customer.Name,
+		Phone:       sql.NullString{String: customer.Phone, Valid: customer.Phone != ""},
+		Description: sql.NullString{String: customer.Description, Valid: customer.Description != ""},
+		Metadata:    metadata,
+		CreatedAt:   unixTime(customer.Created),
+		SyncedAt:    syncedAt,
+		TenantID:    tenancy.ID(ctx),
+	}
+
+	if err := r.queries.UpsertMirroredCustomer(ctx, r.db, params); err != nil {
+		return fmt.Errorf("failed to upsert customer: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteMirroredCustomer removes a deleted provider customer and its payment methods
+func (r *Repository) DeleteMirroredCustomer(ctx context.Context, customerID string) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.DeleteMirroredCustomer")
+	defer span.End()
+
+	if err := r.queries.DeleteCustomerPaymentMethods(ctx, r.db, customerID); err != nil {
+		return fmt.Errorf("failed to delete customer payment methods: %w", err)
+	}
+
+	if err := r.queries.DeleteCustomer(ctx, r.db, customerID); err != nil {
+		return fmt.Errorf("failed to delete customer: %w", err)
+	}
+
+	return nil
+}
+
+// UpsertMirroredPaymentMethod stores a provider payment method unless a newer version is already stored
+func (r *Repository) UpsertMirroredPaymentMethod(ctx context.Context, paymentMethod *stripe.PaymentMethod, syncedAt time.Time) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.UpsertMirroredPaymentMethod")
+	defer span.End()
+
+	metadata, err := nullMetadata(paymentMethod.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payment method metadata: %w", err)
+	}
+
+	params := sqlc.UpsertMirroredPaymentMethodParams{
+		ID:         paymentMethod.ID,
+		Type:       paymentMethod.Type,
+		CustomerID: paymentMethod.Customer,
+		Metadata:   metadata,
+		CreatedAt:  unixTime(paymentMethod.Created),
+		SyncedAt:   syncedAt,
+		TenantID:   tenancy.ID(ctx),
+	}
+	if card := paymentMethod.Card; card != nil {
+		params.CardLast4 = sql.NullString{String: card.Last4, Valid: true}
+		params.CardBrand = sql.NullString{String: card.Brand, Valid: true}
+		params.CardExpMonth = sql.NullInt32{Int32: int32(card.ExpMonth), Valid: true}
+		params.CardExpYear = sql.NullInt32{Int32: int32(card.ExpYear), Valid: true}
+		params.CardFingerprint = sql.NullString{String: card.Fingerprint, Valid: card.Fingerprint != ""}
+	}
+
+	if err := r.queries.UpsertMirroredPaymentMethod(ctx, r.db, params); err != nil {
+		return fmt.Errorf("failed to upsert payment method: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteMirroredPaymentMethod removes a detached payment method unless it was stored after syncedAt
+func (r *Repository) DeleteMirroredPaymentMethod(ctx context.Context, paymentMethodID string, syncedAt time.Time) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.DeleteMirroredPaymentMethod")
+	defer span.End()
+
+	params := sqlc.DeleteMirroredPaymentMethodParams{
+		ID:       paymentMethodID,
+		SyncedAt: syncedAt,
+	}
+
+	if err := r.queries.DeleteMirroredPaymentMethod(ctx, r.db, params); err != nil {
+		return fmt.Errorf("failed to delete payment method: %w", err)
+	}
+
+	return nil
+}
+
+// UpsertMirroredCharge stores a provider charge unless a newer version is already stored
+func (r *Repository) UpsertMirroredCharge(ctx context.Context, charge *stripe.Charge, syncedAt time.Time) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.UpsertMirroredCharge")
+	defer span.End()
+
+	metadata, err := nullMetadata(charge.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal charge metadata: %w", err)
+	}
+
+	params := sqlc.UpsertMirroredChargeParams{
+		ID:              charge.ID,
+		Amount:          charge.Amount,
+		AmountRefunded:  charge.AmountRefunded,
+		Currency:        charge.Currency,
+		Status:          charge.Status,
+		Captured:        charge.Captured,
+		Refunded:        charge.Refunded,
+		Disputed:        charge.Disputed,
+		CustomerID:      charge.CustomerID,
+		PaymentMethodID: sql.NullString{String: charge.PaymentMethodID, Valid: charge.PaymentMethodID != ""},
+		InvoiceID:       charge.InvoiceID,
+		Description:     sql.NullString{String: charge.Description, Valid: charge.Description != ""},
+		Metadata:        metadata,
+		CreatedAt:       unixTime(charge.Created),
+		SyncedAt:        syncedAt,
+		TenantID:        tenancy.ID(ctx),
+	}
+
+	if err := r.queries.UpsertMirroredCharge(ctx, r.db, params); err != nil {
+		return fmt.Errorf("failed to upsert charge: %w", err)
+	}
+
+	return nil
+}
+
+// MarkMirroredChargeDisputed flags a stored charge as disputed
+func (r *Repository) MarkMirroredChargeDisputed(ctx context.Context, chargeID string) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.MarkMirroredChargeDisputed")
+	defer span.End()
+
+	if err := r.queries.MarkMirroredChargeDisputed(ctx, r.db, chargeID); err != nil {
+		return fmt.Errorf("failed to mark charge disputed: %w", err)
+	}
+
+	return nil
+}
+
+// nullMetadata encodes provider metadata for a nullable JSONB column
+func nullMetadata(metadata map[string]string) (pqtype.NullRawMessage, error) {
+	if len(metadata) == 0 {
+		return pqtype.NullRawMessage{}, nil
+	}
+
+	raw, err := json.Marshal(metadata)
+	if err != nil {
+		return pqtype.NullRawMessage{}, err
+	}
+
+	return pqtype.NullRawMessage{RawMessage: raw, Valid: true}, nil
+}
+
+// decodeMetadata decodes metadata stored by nullMetadata
+func decodeMetadata(metadata pqtype.NullRawMessage) map[string]string {
+	if !metadata.Valid {
+		return nil
+	}
+
+	var decoded map[string]string
+	_ = json.Unmarshal(metadata.RawMessage, &decoded)
+	return decoded
+}
+
+// unixTime converts a provider timestamp, storing unset timestamps as NULL
+func unixTime(seconds int64) sql.NullTime {
+	if seconds == 0 {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: time.Unix(seconds, 0).UTC(), Valid: true}
+}
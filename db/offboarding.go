@@ -0,0 +1,222 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/offboarding"
+
+	"github.com/sqlc-dev/pqtype"
+)
+
+// CreateOffboardingExport stores a new pending export
+func (r *Repository) CreateOffboardingExport(ctx context.Context, export *offboarding.Export) (*offboarding.Export, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.CreateOffboardingExport")
+	defer span.End()
+
+	dbExport, err := r.queries.CreateOffboardingExport(ctx, r.db, sqlc.CreateOffboardingExportParams{
+		ID:                 export.ID,
+		TenantID:           export.TenantID,
+		RecipientPublicKey: export.RecipientPublicKey,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create offboarding export: %w", err)
+	}
+
+	return convertOffboardingExport(dbExport), nil
+}
+
+// GetOffboardingExport retrieves an export by ID
+func (r *Repository) GetOffboardingExport(ctx context.Context, id string) (*offboarding.Export, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetOffboardingExport")
+	defer span.End()
+
+	dbExport, err := r.queries.GetOffboardingExport(ctx, r.db, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get offboarding export: %w", err)
+	}
+
+	return convertOffboardingExport(dbExport), nil
+}
+
+// ListOffboardingExports retrieves a tenant's exports, newest first
+func (r *Repository) ListOffboardingExports(ctx context.Context, tenantID string, limit int) ([]*offboarding.Export, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListOffboardingExports")
+	defer span.End()
+
+	dbExports, err := r.queries.ListOffboardingExports(ctx, r.db, sqlc.ListOffboardingExportsParams{
+		TenantID: tenantID,
+		Limit:    int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list offboarding exports: %w", err)
+	}
+
+	return convertOffboardingExports(dbExports), nil
+}
+
+// ListRunnableOffboardingExports retrieves pending exports and exports left
+// running for over an hour, oldest first
+func (r *Repository) ListRunnableOffboardingExports(ctx context.Context, limit int) ([]*offboarding.Export, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListRunnableOffboardingExports")
+	defer span.End()
+
+	dbExports, err := r.queries.ListRunnableOffboardingExports(ctx, r.db, int32(limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list runnable offboarding exports: %w", err)
+	}
+
+	return convertOffboardingExports(dbExports), nil
+}
+
+// ClaimOffboardingExport marks a runnable export as running
+func (r *Repository) ClaimOffboardingExport(ctx context.Context, id string) (*offboarding.Export, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ClaimOffboardingExport")
+	defer span.End()
+
+	dbExport, err := r.queries.ClaimOffboardingExport(ctx, r.db, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim offboarding export: %w", err)
+	}
+
+	return convertOffboardingExport(dbExport), nil
+}
+
+// CompleteOffboardingExport stores a running export's archive and marks it completed
+func (r *Repository) CompleteOffboardingExport(ctx context.Context, export *offboarding.Export, archive []byte) (*offboarding.Export, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.CompleteOffboardingExport")
+	defer span.End()
+
+	manifest, err := json.Marshal(export.Manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	// A retried export overwrites an archive stored by an attempt that did not complete
+	err = r.queries.StoreOffboardingArchive(ctx, r.db, sqlc.StoreOffboardingArchiveParams{
+		ExportID: export.ID,
+		Archive:  archive,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store offboarding archive: %w", err)
+	}
+
+	dbExport, err := r.queries.CompleteOffboardingExport(ctx, r.db, sqlc.CompleteOffboardingExportParams{
+		ID:            export.ID,
+		Manifest:      pqtype.NullRawMessage{RawMessage: manifest, Valid: true},
+		ArchiveSha256: export.ArchiveSHA256,
+		ArchiveSize:   export.ArchiveSize,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete offboarding export: %w", err)
+	}
+
+	return convertOffboardingExport(dbExport), nil
+}
+
+// FailOffboardingExport marks a running export as failed
+func (r *Repository) FailOffboardingExport(ctx context.Context, id, reason string) (*offboarding.Export, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.FailOffboardingExport")
+	defer span.End()
+
+	dbExport, err := r.queries.FailOffboardingExport(ctx, r.db, sqlc.FailOffboardingExportParams{
+		ID:    id,
+		Error: reason,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fail offboarding export: %w", err)
+	}
+
+	return convertOffboardingExport(dbExport), nil
+}
+
+// RecordOffboardingPANMigration records a PAN migration request on a completed export
+func (r *Repository) RecordOffboardingPANMigration(ctx context.Context, export *offboarding.Export) (*offboarding.Export, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.RecordOffboardingPANMigration")
+	defer span.End()
+
+	dbExport, err := r.queries.RecordOffboardingPANMigration(ctx, r.db, sqlc.RecordOffboardingPANMigrationParams{
+		ID:                      export.ID,
+		PanMigrationStatus:      export.PANMigrationStatus,
+		PanMigrationDestination: export.PANMigrationDestination,
+		PanMigrationReference:   export.PANMigrationReference,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record PAN migration: %w", err)
+	}
+
+	return convertOffboardingExport(dbExport), nil
+}
+
+// GetOffboardingArchive retrieves an export's encrypted archive
+func (r *Repository) GetOffboardingArchive(ctx context.Context, id string) ([]byte, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetOffboardingArchive")
+	defer span.End()
+
+	archive, err := r.queries.GetOffboardingArchive(ctx, r.db, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get offboarding archive: %w", err)
+	}
+
+	return archive, nil
+}
+
+// ListTenantCustomerIDs retrieves the IDs of the customers created for a
+// tenant, oldest first
+func (r *Repository) ListTenantCustomerIDs(ctx context.Context, tenantID string) ([]string, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListTenantCustomerIDs")
+	defer span.End()
+
+	customerIDs, err := r.queries.ListTenantCustomerIDs(ctx, r.db, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenant customers: %w", err)
+	}
+
+	return customerIDs, nil
+}
+
+// convertOffboardingExports converts database exports
+func convertOffboardingExports(dbExports []sqlc.OffboardingExport) []*offboarding.Export {
+	exports := make([]*offboarding.Export, len(dbExports))
+	for i, dbExport := range dbExports {
+		exports[i] = convertOffboardingExport(dbExport)
+	}
+	return exports
+}
+
+// convertOffboardingExport converts a database export
+func convertOffboardingExport(dbExport sqlc.OffboardingExport) *offboarding.Export {
+	export := &offboarding.Export{
+		ID:                      dbExport.ID,
+		TenantID:                dbExport.TenantID,
+		Status:                  dbExport.Status,
+		RecipientPublicKey:      dbExport.RecipientPublicKey,
+		ArchiveSHA256:           dbExport.ArchiveSha256,
+		ArchiveSize:             dbExport.ArchiveSize,
+		Error:                   dbExport.Error,
+		PANMigrationStatus:      dbExport.PanMigrationStatus,
+		PANMigrationDestination: dbExport.PanMigrationDestination,
+		PANMigrationReference:   dbExport.PanMigrationReference,
+		CreatedAt:               dbExport.CreatedAt.Time,
+		UpdatedAt:               dbExport.UpdatedAt.Time,
+	}
+	if dbExport.Manifest.Valid {
+		var manifest offboarding.Manifest
+		if err := json.Unmarshal(dbExport.Manifest.RawMessage, &manifest); err == nil {
+			export.Manifest = &manifest
+		}
+	}
+	if dbExport.PanMigrationRequestedAt.Valid {
+		export.PANMigrationRequestedAt = &dbExport.PanMigrationRequestedAt.Time
+	}
+	if dbExport.StartedAt.Valid {
+		export.StartedAt = &dbExport.StartedAt.Time
+	}
+	if dbExport.CompletedAt.Valid {
+		export.CompletedAt = &dbExport.CompletedAt.Time
+	}
+
+	return export
+}
@@ -0,0 +1,216 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/paymentlinks"
+	"apis/payments/services/tenancy"
+)
+
+// CreatePaymentLink stores a new payment link
+func (r *Repository) CreatePaymentLink(ctx context.Context, link *paymentlinks.PaymentLink) (*paymentlinks.PaymentLink, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.CreatePaymentLink")
+	defer span.End()
+
+	metadata, err := json.Marshal(link.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payment link metadata: %w", err)
+	}
+
+	params := sqlc.CreatePaymentLinkParams{
+		ID:          link.ID,
+		Url:         link.URL,
+		Amount:      link.Amount,
+		Currency:    link.Currency,
+		ProductName: link.ProductName,
+		PriceID:     link.PriceID,
+		MaxUses:     link.MaxUses,
+		Metadata:    metadata,
+		TenantID:    tenancy.ID(ctx),
+	}
+	if link.ExpiresAt != nil {
+		params.ExpiresAt = sql.NullTime{Time: *link.ExpiresAt, Valid: true}
+	}
+
+	dbLink, err := r.queries.CreatePaymentLink(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create payment link: %w", err)
+	}
+
+	return convertPaymentLink(dbLink), nil
+}
+
+// GetPaymentLink retrieves a payment link by ID
+func (r *Repository) GetPaymentLink(ctx context.Context, linkID string) (*paymentlinks.PaymentLink, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetPaymentLink")
+	defer span.End()
+
+	dbLink, err := r.queries.GetPaymentLink(ctx, r.db, sqlc.GetPaymentLinkParams{
+		ID:       linkID,
+		TenantID: scopedTenant(ctx),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment link: %w", err)
+	}
+
+	return convertPaymentLink(dbLink), nil
+}
+
+// ListPaymentLinks retrieves payment links, newest first
+func (r *Repository) ListPaymentLinks(ctx context.Context, filter paymentlinks.Filter) ([]*paymentlinks.PaymentLink, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListPaymentLinks")
+	defer span.End()
+
+	dbLinks, err := r.queries.ListPaymentLinks(ctx, r.db, sqlc.ListPaymentLinksParams{
+		Active:   filter.ActiveOnly,
+		Limit:    int32(filter.Limit),
+		TenantID: scopedTenant(ctx),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payment links: %w", err)
+	}
+
+	return convertPaymentLinks(dbLinks), nil
+}
+
+// DeactivatePaymentLink marks an active payment link inactive. It returns
+// sql.ErrNoRows if the link is missing or already inactive.
+func (r *Repository) DeactivatePaymentLink(ctx context.Context, linkID string, at time.Time) (*paymentlinks.PaymentLink, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.DeactivatePaymentLink")
+	defer span.End()
+
+	dbLink, err := r.queries.DeactivatePaymentLink(ctx, r.db, sqlc.DeactivatePaymentLinkParams{
+		ID:            linkID,
+		DeactivatedAt: sql.NullTime{Time: at, Valid: true},
+		TenantID:      scopedTenant(ctx),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to deactivate payment link: %w", err)
+	}
+
+	return convertPaymentLink(dbLink), nil
+}
+
+// ListExpiredPaymentLinks retrieves active payment links whose expiry has passed at now
+func (r *Repository) ListExpiredPaymentLinks(ctx context.Context, now time.Time) ([]*paymentlinks.PaymentLink, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListExpiredPaymentLinks")
+	defer span.End()
+
+	dbLinks, err := r.queries.ListExpiredPaymentLinks(ctx, r.db, sql.NullTime{Time: now, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired payment links: %w", err)
+	}
+
+	return convertPaymentLinks(dbLinks), nil
+}
+
+// InsertPaymentLinkConversion records a conversion, returning false if its
+// checkout session was already recorded
+func (r *Repository) InsertPaymentLinkConversion(ctx context.Context, conversion *paymentlinks.Conversion) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.InsertPaymentLinkConversion")
+	defer span.End()
+
+	rows, err := r.queries.InsertPaymentLinkConversion(ctx, r.db, sqlc.InsertPaymentLinkConversionParams{
+		SessionID:       conversion.SessionID,
+		PaymentLinkID:   conversion.PaymentLinkID,
+		AmountTotal:     conversion.AmountTotal,
+		Currency:        conversion.Currency,
+		CustomerID:      conversion.CustomerID,
+		PaymentIntentID: conversion.PaymentIntentID,
+		CompletedAt:     conversion.CompletedAt,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to insert payment link conversion: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// AddPaymentLinkConversion adds a conversion of amount to a payment link's totals
+func (r *Repository) AddPaymentLinkConversion(ctx context.Context, linkID string, amount int64) (*paymentlinks.PaymentLink, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.AddPaymentLinkConversion")
+	defer span.End()
+
+	dbLink, err := r.queries.AddPaymentLinkConversion(ctx, r.db, sqlc.AddPaymentLinkConversionParams{
+		ID:              linkID,
+		AmountCollected: amount,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to add payment link conversion: %w", err)
+	}
+
+	return convertPaymentLink(dbLink), nil
+}
+
+// ListPaymentLinkConversions retrieves a payment link's conversions, newest first
+func (r *Repository) ListPaymentLinkConversions(ctx context.Context, linkID string, limit int) ([]*paymentlinks.Conversion, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListPaymentLinkConversions")
+	defer span.End()
+
+	dbConversions, err := r.queries.ListPaymentLinkConversions(ctx, r.db, sqlc.ListPaymentLinkConversionsParams{
+		PaymentLinkID: linkID,
+		Limit:         int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payment link conversions: %w", err)
+	}
+
+	result := make([]*paymentlinks.Conversion, len(dbConversions))
+	for i, dbConversion := range dbConversions {
+		result[i] = &paymentlinks.Conversion{
+			SessionID:       dbConversion.SessionID,
+			PaymentLinkID:   dbConversion.PaymentLinkID,
+			AmountTotal:     dbConversion.AmountTotal,
+			Currency:        dbConversion.Currency,
+			CustomerID:      dbConversion.CustomerID,
+			PaymentIntentID: dbConversion.PaymentIntentID,
+			CompletedAt:     dbConversion.CompletedAt,
+		}
+	}
+
+	return result, nil
+}
+
+// convertPaymentLinks converts database payment links to service payment links
+func convertPaymentLinks(dbLinks []sqlc.PaymentLink) []*paymentlinks.PaymentLink {
+	result := make([]*paymentlinks.PaymentLink, len(dbLinks))
+	for i, dbLink := range dbLinks {
+		result[i] = convertPaymentLink(dbLink)
+	}
+	return result
+}
+
+// convertPaymentLink converts a database payment link to a service payment link
+func convertPaymentLink(dbLink sqlc.PaymentLink) *paymentlinks.PaymentLink {
+	link := &paymentlinks.PaymentLink{
+		ID:              dbLink.ID,
+		URL:             dbLink.Url,
+		Amount:          dbLink.Amount,
+		Currency:        dbLink.Currency,
+		ProductName:     dbLink.ProductName,
+		PriceID:         dbLink.PriceID,
+		Active:          dbLink.Active,
+		MaxUses:         dbLink.MaxUses,
+		Conversions:     dbLink.Conversions,
+		AmountCollected: dbLink.AmountCollected,
+		CreatedAt:       dbLink.CreatedAt.Time,
+	}
+
+	if dbLink.ExpiresAt.Valid {
+		expiresAt := dbLink.ExpiresAt.Time
+		link.ExpiresAt = &expiresAt
+	}
+	if dbLink.DeactivatedAt.Valid {
+		deactivatedAt := dbLink.DeactivatedAt.Time
+		link.DeactivatedAt = &deactivatedAt
+	}
+
+	_ = json.Unmarshal(dbLink.Metadata, &link.Metadata)
+
+	return link
+}
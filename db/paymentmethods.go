@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/paymentmethods"
+)
+
+// GetPaymentMethodPreference retrieves the order a customer's payment
+// methods are charged in
+func (r *Repository) GetPaymentMethodPreference(ctx context.Context, tenantID, customerID string) (*paymentmethods.Preference, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetPaymentMethodPreference")
+	defer span.End()
+
+	dbPreference, err := r.queries.GetPaymentMethodPreference(ctx, r.db, sqlc.GetPaymentMethodPreferenceParams{
+		TenantID:   tenantID,
+		CustomerID: customerID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment method preference: %w", err)
+	}
+
+	return convertPaymentMethodPreference(dbPreference), nil
+}
+
+// UpsertPaymentMethodPreference creates or replaces the order a customer's
+// payment methods are charged in
+func (r *Repository) UpsertPaymentMethodPreference(ctx context.Context, preference *paymentmethods.Preference) (*paymentmethods.Preference, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.UpsertPaymentMethodPreference")
+	defer span.End()
+
+	backups, err := json.Marshal(preference.BackupPaymentMethods)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal backup payment methods: %w", err)
+	}
+
+	dbPreference, err := r.queries.UpsertPaymentMethodPreference(ctx, r.db, sqlc.UpsertPaymentMethodPreferenceParams{
+		TenantID:             preference.TenantID,
+		CustomerID:           preference.CustomerID,
+		DefaultPaymentMethod: preference.DefaultPaymentMethod,
+		BackupPaymentMethods: backups,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert payment method preference: %w", err)
+	}
+
+	return convertPaymentMethodPreference(dbPreference), nil
+}
+
+// convertPaymentMethodPreference converts a database payment method preference
+func convertPaymentMethodPreference(dbPreference sqlc.PaymentMethodPreference) *paymentmethods.Preference {
+	preference := &paymentmethods.Preference{
+		TenantID:             dbPreference.TenantID,
+		CustomerID:           dbPreference.CustomerID,
+		DefaultPaymentMethod: dbPreference.DefaultPaymentMethod,
+		BackupPaymentMethods: []string{},
+		CreatedAt:            dbPreference.CreatedAt.Time,
+		UpdatedAt:            dbPreference.UpdatedAt.Time,
+	}
+	_ = json.Unmarshal(dbPreference.BackupPaymentMethods, &preference.BackupPaymentMethods)
+
+	return preference
+}
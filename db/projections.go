@@ -0,0 +1,114 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/projections"
+)
+
+// UpsertChargeRow creates or refreshes a charge list row
+func (r *Repository) UpsertChargeRow(ctx context.Context, row *projections.ChargeRow) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.UpsertChargeRow")
+	defer span.End()
+
+	params := sqlc.UpsertChargeListRowParams{
+		ChargeID:       row.ChargeID,
+		TenantID:       row.TenantID,
+		CustomerID:     row.CustomerID,
+		CustomerEmail:  row.CustomerEmail,
+		CustomerName:   row.CustomerName,
+		PlanName:       row.PlanName,
+		Amount:         row.Amount,
+		AmountRefunded: row.AmountRefunded,
+		Currency:       row.Currency,
+		Status:         row.Status,
+		Description:    row.Description,
+		ChargeCreated:  row.ChargeCreated,
+	}
+
+	if err := r.queries.UpsertChargeListRow(ctx, r.db, params); err != nil {
+		return fmt.Errorf("failed to upsert charge list row: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateChargeRowRefund records the latest refund on a charge list row
+func (r *Repository) UpdateChargeRowRefund(ctx context.Context, chargeID, refundID, refundStatus string) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.UpdateChargeRowRefund")
+	defer span.End()
+
+	params := sqlc.UpdateChargeListRowRefundParams{
+		ChargeID:         chargeID,
+		LastRefundID:     refundID,
+		LastRefundStatus: refundStatus,
+	}
+
+	if err := r.queries.UpdateChargeListRowRefund(ctx, r.db, params); err != nil {
+		return fmt.Errorf("failed to update charge list row refund: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateChargeRowsCustomer updates the customer details on a customer's charge list rows
+func (r *Repository) UpdateChargeRowsCustomer(ctx context.Context, customerID, email, name string) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.UpdateChargeRowsCustomer")
+	defer span.End()
+
+	params := sqlc.UpdateChargeListRowsCustomerParams{
+		CustomerID:    customerID,
+		CustomerEmail: email,
+		CustomerName:  name,
+	}
+
+	if err := r.queries.UpdateChargeListRowsCustomer(ctx, r.db, params); err != nil {
+		return fmt.Errorf("failed to update charge list row customer: %w", err)
+	}
+
+	return nil
+}
+
+// ListChargeRows lists charge list rows matching a filter, newest first
+func (r *Repository) ListChargeRows(ctx context.Context, filter projections.ChargeFilter) ([]*projections.ChargeRow, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListChargeRows")
+	defer span.End()
+
+	params := sqlc.ListChargeListRowsParams{
+		TenantID:   filter.TenantID,
+		CustomerID: filter.CustomerID,
+		Status:     filter.Status,
+		Limit:      int32(filter.Limit),
+		Offset:     int32(filter.Offset),
+	}
+
+	dbRows, err := r.queries.ListChargeListRows(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list charge list rows: %w", err)
+	}
+
+	rows := make([]*projections.ChargeRow, len(dbRows))
+	for i, dbRow := range dbRows {
+		rows[i] = &projections.ChargeRow{
+			ChargeID:         dbRow.ChargeID,
+			TenantID:         dbRow.TenantID,
+			CustomerID:       dbRow.CustomerID,
+			CustomerEmail:    dbRow.CustomerEmail,
+			CustomerName:     dbRow.CustomerName,
+			PlanName:         dbRow.PlanName,
+			Amount:           dbRow.Amount,
+			AmountRefunded:   dbRow.AmountRefunded,
+			Currency:         dbRow.Currency,
+			Status:           dbRow.Status,
+			Description:      dbRow.Description,
+			LastRefundID:     dbRow.LastRefundID,
+			LastRefundStatus: dbRow.LastRefundStatus,
+			ChargeCreated:    dbRow.ChargeCreated,
+			UpdatedAt:        dbRow.UpdatedAt.Time,
+		}
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,180 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/tenantcredentials"
+)
+
+// GetProviderCredential retrieves a tenant's encrypted credentials for a provider
+func (r *Repository) GetProviderCredential(ctx context.Context, tenantID, provider string) (*tenantcredentials.Record, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetProviderCredential")
+	defer span.End()
+
+	dbCredential, err := r.queries.GetProviderCredential(ctx, r.db, sqlc.GetProviderCredentialParams{
+		TenantID: tenantID,
+		Provider: provider,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get provider credential: %w", err)
+	}
+
+	return convertProviderCredential(dbCredential)
+}
+
+// ListProviderCredentials retrieves every provider credential a tenant has stored
+func (r *Repository) ListProviderCredentials(ctx context.Context, tenantID string) ([]*tenantcredentials.Record, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListProviderCredentials")
+	defer span.End()
+
+	dbCredentials, err := r.queries.ListProviderCredentials(ctx, r.db, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list provider credentials: %w", err)
+	}
+
+	records := make([]*tenantcredentials.Record, len(dbCredentials))
+	for i, dbCredential := range dbCredentials {
+		if records[i], err = convertProviderCredential(dbCredential); err != nil {
+			return nil, err
+		}
+	}
+
+	return records, nil
+}
+
+// UpsertProviderCredential creates or replaces a tenant's credentials for a provider
+func (r *Repository) UpsertProviderCredential(ctx context.Context, record *tenantcredentials.Record) (*tenantcredentials.Record, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.UpsertProviderCredential")
+	defer span.End()
+
+	maskedFields, err := json.Marshal(record.MaskedFields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal masked fields: %w", err)
+	}
+
+	params := sqlc.UpsertProviderCredentialParams{
+		TenantID:     record.TenantID,
+		Provider:     record.Provider,
+		Ciphertext:   record.Ciphertext,
+		MaskedFields: maskedFields,
+		Version:      int32(record.Version),
+		VerifiedAt:   record.VerifiedAt,
+	}
+
+	dbCredential, err := r.queries.UpsertProviderCredential(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert provider credential: %w", err)
+	}
+
+	return convertProviderCredential(dbCredential)
+}
+
+// DeleteProviderCredential removes a tenant's credentials for a provider, reporting whether any existed
+func (r *Repository) DeleteProviderCredential(ctx context.Context, tenantID, provider string) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.DeleteProviderCredential")
+	defer span.End()
+
+	rows, err := r.queries.DeleteProviderCredential(ctx, r.db, sqlc.DeleteProviderCredentialParams{
+		TenantID: tenantID,
+		Provider: provider,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to delete provider credential: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// CreateProviderCredentialAudit records a change to a tenant's credentials
+func (r *Repository) CreateProviderCredentialAudit(ctx context.Context, entry *tenantcredentials.AuditEntry) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.CreateProviderCredentialAudit")
+	defer span.End()
+
+	changedFields, err := json.Marshal(entry.ChangedFields)
+	if err != nil {
+		return fmt.Errorf("failed to marshal changed fields: %w", err)
+	}
+
+	params := sqlc.CreateProviderCredentialAuditParams{
+		ID:            entry.ID,
+		TenantID:      entry.TenantID,
+		Provider:      entry.Provider,
+		Action:        entry.Action,
+		Version:       int32(entry.Version),
+		ChangedFields: changedFields,
+		ApiKeyID:      entry.APIKeyID,
+		OperatorID:    entry.OperatorID,
+	}
+
+	if err := r.queries.CreateProviderCredentialAudit(ctx, r.db, params); err != nil {
+		return fmt.Errorf("failed to create provider credential audit: %w", err)
+	}
+
+	return nil
+}
+
+// ListProviderCredentialAudit retrieves the most recent changes to a tenant's credentials for a provider
+func (r *Repository) ListProviderCredentialAudit(ctx context.Context, tenantID, provider string, limit int) ([]*tenantcredentials.AuditEntry, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListProviderCredentialAudit")
+	defer span.End()
+
+	dbEntries, err := r.queries.ListProviderCredentialAudit(ctx, r.db, sqlc.ListProviderCredentialAuditParams{
+		TenantID: tenantID,
+		Provider: provider,
+		Limit:    int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list provider credential audit: %w", err)
+	}
+
+	entries := make([]*tenantcredentials.AuditEntry, len(dbEntries))
+	for i, dbEntry := range dbEntries {
+		if entries[i], err = convertProviderCredentialAudit(dbEntry); err != nil {
+			return nil, err
+		}
+	}
+
+	return entries, nil
+}
+
+// convertProviderCredential converts a database provider credential
+func convertProviderCredential(dbCredential sqlc.ProviderCredential) (*tenantcredentials.Record, error) {
+	var maskedFields map[string]string
+	if err := json.Unmarshal(dbCredential.MaskedFields, &maskedFields); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal masked fields: %w", err)
+	}
+
+	return &tenantcredentials.Record{
+		TenantID:     dbCredential.TenantID,
+		Provider:     dbCredential.Provider,
+		Ciphertext:   dbCredential.Ciphertext,
+		MaskedFields: maskedFields,
+		Version:      int(dbCredential.Version),
+		VerifiedAt:   dbCredential.VerifiedAt,
+		CreatedAt:    dbCredential.CreatedAt.Time,
+		UpdatedAt:    dbCredential.UpdatedAt.Time,
+	}, nil
+}
+
+// convertProviderCredentialAudit converts a database provider credential audit entry
+func convertProviderCredentialAudit(dbEntry sqlc.ProviderCredentialAudit) (*tenantcredentials.AuditEntry, error) {
+	var changedFields []string
+	if err := json.Unmarshal(dbEntry.ChangedFields, &changedFields); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal changed fields: %w", err)
+	}
+
+	return &tenantcredentials.AuditEntry{
+		ID:            dbEntry.ID,
+		TenantID:      dbEntry.TenantID,
+		Provider:      dbEntry.Provider,
+		Action:        dbEntry.Action,
+		Version:       int(dbEntry.Version),
+		ChangedFields: changedFields,
+		APIKeyID:      dbEntry.ApiKeyID,
+		OperatorID:    dbEntry.OperatorID,
+		CreatedAt:     dbEntry.CreatedAt.Time,
+	}, nil
+}
@@ -0,0 +1,282 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/quarantine"
+)
+
+// CreateQuarantine stores a new quarantine
+func (r *Repository) CreateQuarantine(ctx context.Context, q *quarantine.Quarantine) (*quarantine.Quarantine, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.CreateQuarantine")
+	defer span.End()
+
+	params := sqlc.CreateQuarantineParams{
+		ID:          q.ID,
+		SubjectType: q.SubjectType,
+		SubjectID:   q.SubjectID,
+		Reason:      q.Reason,
+		CreatedBy:   q.CreatedBy,
+	}
+
+	dbQuarantine, err := r.queries.CreateQuarantine(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create quarantine: %w", err)
+	}
+
+	return convertQuarantine(dbQuarantine), nil
+}
+
+// GetQuarantine retrieves a quarantine
+func (r *Repository) GetQuarantine(ctx context.Context, id string) (*quarantine.Quarantine, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetQuarantine")
+	defer span.End()
+
+	dbQuarantine, err := r.queries.GetQuarantine(ctx, r.db, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quarantine: %w", err)
+	}
+
+	return convertQuarantine(dbQuarantine), nil
+}
+
+// GetActiveQuarantine retrieves the quarantine currently covering a subject
+func (r *Repository) GetActiveQuarantine(ctx context.Context, subjectType, subjectID string) (*quarantine.Quarantine, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetActiveQuarantine")
+	defer span.End()
+
+	params := sqlc.GetActiveQuarantineParams{SubjectType: subjectType, SubjectID: subjectID}
+	dbQuarantine, err := r.queries.GetActiveQuarantine(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active quarantine: %w", err)
+	}
+
+	return convertQuarantine(dbQuarantine), nil
+}
+
+// ListActiveQuarantines retrieves quarantines that have not been lifted
+func (r *Repository) ListActiveQuarantines(ctx context.Context) ([]*quarantine.Quarantine, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListActiveQuarantines")
+	defer span.End()
+
+	dbQuarantines, err := r.queries.ListActiveQuarantines(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list quarantines: %w", err)
+	}
+
+	quarantines := make([]*quarantine.Quarantine, len(dbQuarantines))
+	for i, dbQuarantine := range dbQuarantines {
+		quarantines[i] = convertQuarantine(dbQuarantine)
+	}
+
+	return quarantines, nil
+}
+
+// LiftQuarantine ends an active quarantine
+func (r *Repository) LiftQuarantine(ctx context.Context, id, liftedBy string) (*quarantine.Quarantine, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.LiftQuarantine")
+	defer span.End()
+
+	params := sqlc.LiftQuarantineParams{
+		ID:       id,
+		LiftedBy: sql.NullString{String: liftedBy, Valid: liftedBy != ""},
+	}
+
+	dbQuarantine, err := r.queries.LiftQuarantine(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lift quarantine: %w", err)
+	}
+
+	return convertQuarantine(dbQuarantine), nil
+}
+
+// RecordQuarantineActivity stores a flagged request
+func (r *Repository) RecordQuarantineActivity(ctx context.Context, activity *quarantine.Activity) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.RecordQuarantineActivity")
+	defer span.End()
+
+	params := sqlc.RecordQuarantineActivityParams{
+		ID:             activity.ID,
+		QuarantineID:   activity.QuarantineID,
+		TenantID:       activity.TenantID,
+		ApiKeyID:       activity.APIKeyID,
+		Method:         activity.Method,
+		Path:           activity.Path,
+		HeldMutationID: activity.HeldMutationID,
+	}
+
+	if err := r.queries.RecordQuarantineActivity(ctx, r.db, params); err != nil {
+		return fmt.Errorf("failed to record quarantine activity: %w", err)
+	}
+
+	return nil
+}
+
+// ListQuarantineActivity retrieves the flagged requests of a quarantine, newest first
+func (r *Repository) ListQuarantineActivity(ctx context.Context, quarantineID string, limit int) ([]*quarantine.Activity, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListQuarantineActivity")
+	defer span.End()
+
+	params := sqlc.ListQuarantineActivityParams{QuarantineID: quarantineID, Limit: int32(limit)}
+	dbActivity, err := r.queries.ListQuarantineActivity(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list quarantine activity: %w", err)
+	}
+
+	activity := make([]*quarantine.Activity, len(dbActivity))
+	for i, row := range dbActivity {
+		activity[i] = &quarantine.Activity{
+			ID:             row.ID,
+			QuarantineID:   row.QuarantineID,
+			TenantID:       row.TenantID,
+			APIKeyID:       row.ApiKeyID,
+			Method:         row.Method,
+			Path:           row.Path,
+			HeldMutationID: row.HeldMutationID,
+			CreatedAt:      row.CreatedAt.Time,
+		}
+	}
+
+	return activity, nil
+}
+
+// CreateHeldMutation stores a mutation held from a quarantined caller
+func (r *Repository) CreateHeldMutation(ctx context.Context, mutation *quarantine.HeldMutation) (*quarantine.HeldMutation, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.CreateHeldMutation")
+	defer span.End()
+
+	params := sqlc.CreateHeldMutationParams{
+		ID:           mutation.ID,
+		QuarantineID: mutation.QuarantineID,
+		TenantID:     mutation.TenantID,
+		ApiKeyID:     mutation.APIKeyID,
+		OperatorID:   mutation.OperatorID,
+		Method:       mutation.Method,
+		Path:         mutation.Path,
+		ContentType:  mutation.ContentType,
+		Body:         mutation.Body,
+		Status:       mutation.Status,
+	}
+
+	dbMutation, err := r.queries.CreateHeldMutation(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create held mutation: %w", err)
+	}
+
+	return convertHeldMutation(dbMutation), nil
+}
+
+// GetHeldMutation retrieves a held mutation
+func (r *Repository) GetHeldMutation(ctx context.Context, id string) (*quarantine.HeldMutation, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetHeldMutation")
+	defer span.End()
+
+	dbMutation, err := r.queries.GetHeldMutation(ctx, r.db, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get held mutation: %w", err)
+	}
+
+	return convertHeldMutation(dbMutation), nil
+}
+
+// ListHeldMutations retrieves held mutations, optionally in one status, oldest first
+func (r *Repository) ListHeldMutations(ctx context.Context, status string) ([]*quarantine.HeldMutation, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListHeldMutations")
+	defer span.End()
+
+	dbMutations, err := r.queries.ListHeldMutations(ctx, r.db, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list held mutations: %w", err)
+	}
+
+	mutations := make([]*quarantine.HeldMutation, len(dbMutations))
+	for i, dbMutation := range dbMutations {
+		mutations[i] = convertHeldMutation(dbMutation)
+	}
+
+	return mutations, nil
+}
+
+// DecideHeldMutation records the decision on a pending held mutation
+func (r *Repository) DecideHeldMutation(ctx context.Context, id, status, decidedBy string) (*quarantine.HeldMutation, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.DecideHeldMutation")
+	defer span.End()
+
+	params := sqlc.DecideHeldMutationParams{
+		ID:        id,
+		Status:    status,
+		DecidedBy: sql.NullString{String: decidedBy, Valid: decidedBy != ""},
+	}
+
+	dbMutation, err := r.queries.DecideHeldMutation(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decide held mutation: %w", err)
+	}
+
+	return convertHeldMutation(dbMutation), nil
+}
+
+// RecordHeldMutationResult stores the outcome of a released mutation
+func (r *Repository) RecordHeldMutationResult(ctx context.Context, id, status string, responseStatus int, responseBody string) (*quarantine.HeldMutation, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.RecordHeldMutationResult")
+	defer span.End()
+
+	params := sqlc.RecordHeldMutationResultParams{
+		ID:             id,
+		Status:         status,
+		ResponseStatus: int32(responseStatus),
+		ResponseBody:   responseBody,
+	}
+
+	dbMutation, err := r.queries.RecordHeldMutationResult(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record held mutation result: %w", err)
+	}
+
+	return convertHeldMutation(dbMutation), nil
+}
+
+// convertQuarantine converts a database quarantine to a service quarantine
+func convertQuarantine(dbQuarantine sqlc.Quarantine) *quarantine.Quarantine {
+	q := &quarantine.Quarantine{
+		ID:          dbQuarantine.ID,
+		SubjectType: dbQuarantine.SubjectType,
+		SubjectID:   dbQuarantine.SubjectID,
+		Reason:      dbQuarantine.Reason,
+		CreatedBy:   dbQuarantine.CreatedBy,
+		LiftedBy:    dbQuarantine.LiftedBy.String,
+		CreatedAt:   dbQuarantine.CreatedAt.Time,
+	}
+	if dbQuarantine.LiftedAt.Valid {
+		q.LiftedAt = &dbQuarantine.LiftedAt.Time
+	}
+	return q
+}
+
+// convertHeldMutation converts a database held mutation to a service held mutation
+func convertHeldMutation(dbMutation sqlc.HeldMutation) *quarantine.HeldMutation {
+	mutation := &quarantine.HeldMutation{
+		ID:             dbMutation.ID,
+		QuarantineID:   dbMutation.QuarantineID,
+		TenantID:       dbMutation.TenantID,
+		APIKeyID:       dbMutation.ApiKeyID,
+		OperatorID:     dbMutation.OperatorID,
+		Method:         dbMutation.Method,
+		Path:           dbMutation.Path,
+		ContentType:    dbMutation.ContentType,
+		Body:           dbMutation.Body,
+		Status:         dbMutation.Status,
+		DecidedBy:      dbMutation.DecidedBy.String,
+		ResponseStatus: int(dbMutation.ResponseStatus),
+		ResponseBody:   dbMutation.ResponseBody,
+		CreatedAt:      dbMutation.CreatedAt.Time,
+		UpdatedAt:      dbMutation.UpdatedAt.Time,
+	}
+	if dbMutation.DecidedAt.Valid {
+		mutation.DecidedAt = &dbMutation.DecidedAt.Time
+	}
+	return mutation
+}
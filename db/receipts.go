@@ -0,0 +1,54 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/receipts"
+)
+
+// GetReceiptSettings retrieves a tenant's receipt settings
+func (r *Repository) GetReceiptSettings(ctx context.Context, tenantID string) (*receipts.Settings, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetReceiptSettings")
+	defer span.End()
+
+	dbSettings, err := r.queries.GetReceiptSettings(ctx, r.db, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get receipt settings: %w", err)
+	}
+
+	return convertReceiptSettings(dbSettings), nil
+}
+
+// UpsertReceiptSettings creates or replaces a tenant's receipt settings
+func (r *Repository) UpsertReceiptSettings(ctx context.Context, settings *receipts.Settings) (*receipts.Settings, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.UpsertReceiptSettings")
+	defer span.End()
+
+	dbSettings, err := r.queries.UpsertReceiptSettings(ctx, r.db, sqlc.UpsertReceiptSettingsParams{
+		TenantID:          settings.TenantID,
+		SendReceiptEmails: settings.SendReceiptEmails,
+		DescriptorSuffix:  settings.DescriptorSuffix,
+		BusinessName:      settings.BusinessName,
+		SupportEmail:      settings.SupportEmail,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert receipt settings: %w", err)
+	}
+
+	return convertReceiptSettings(dbSettings), nil
+}
+
+// convertReceiptSettings converts database receipt settings
+func convertReceiptSettings(dbSettings sqlc.ReceiptSetting) *receipts.Settings {
+	return &receipts.Settings{
+		TenantID:          dbSettings.TenantID,
+		SendReceiptEmails: dbSettings.SendReceiptEmails,
+		DescriptorSuffix:  dbSettings.DescriptorSuffix,
+		BusinessName:      dbSettings.BusinessName,
+		SupportEmail:      dbSettings.SupportEmail,
+		CreatedAt:         dbSettings.CreatedAt.Time,
+		UpdatedAt:         dbSettings.UpdatedAt.Time,
+	}
+}
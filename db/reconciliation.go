@@ -0,0 +1,228 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/money"
+	"apis/payments/services/reconciliation"
+	"apis/payments/services/stripe"
+)
+
+// CreateReconciliationRun stores a new reconciliation run
+func (r *Repository) CreateReconciliationRun(ctx context.Context, run *reconciliation.Run) (*reconciliation.Run, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.CreateReconciliationRun")
+	defer span.End()
+
+	params := sqlc.CreateReconciliationRunParams{
+		ID:          run.ID,
+		Status:      run.Status,
+		PeriodStart: run.PeriodStart,
+		PeriodEnd:   run.PeriodEnd,
+		StartedAt:   run.StartedAt,
+	}
+
+	dbRun, err := r.queries.CreateReconciliationRun(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create reconciliation run: %w", err)
+	}
+
+	return convertReconciliationRun(dbRun), nil
+}
+
+// CompleteReconciliationRun stores the outcome and counts of a run
+func (r *Repository) CompleteReconciliationRun(ctx context.Context, run *reconciliation.Run) (*reconciliation.Run, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.CompleteReconciliationRun")
+	defer span.End()
+
+	params := sqlc.CompleteReconciliationRunParams{
+		ID:                  run.ID,
+		Status:              run.Status,
+		TransactionsChecked: run.TransactionsChecked,
+		PayoutsChecked:      run.PayoutsChecked,
+		Matched:             run.Matched,
+		Mismatches:          run.Mismatches,
+		Error:               run.Error,
+	}
+	if run.CompletedAt != nil {
+		params.CompletedAt = sql.NullTime{Time: *run.CompletedAt, Valid: true}
+	}
+
+	dbRun, err := r.queries.CompleteReconciliationRun(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete reconciliation run: %w", err)
+	}
+
+	return convertReconciliationRun(dbRun), nil
+}
+
+// GetReconciliationRun retrieves a reconciliation run by ID
+func (r *Repository) GetReconciliationRun(ctx context.Context, runID string) (*reconciliation.Run, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetReconciliationRun")
+	defer span.End()
+
+	dbRun, err := r.queries.GetReconciliationRun(ctx, r.db, runID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reconciliation run: %w", err)
+	}
+
+	return convertReconciliationRun(dbRun), nil
+}
+
+// ListReconciliationRuns retrieves the most recent reconciliation runs
+func (r *Repository) ListReconciliationRuns(ctx context.Context, limit int) ([]*reconciliation.Run, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListReconciliationRuns")
+	defer span.End()
+
+	dbRuns, err := r.queries.ListReconciliationRuns(ctx, r.db, int32(limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reconciliation runs: %w", err)
+	}
+
+	runs := make([]*reconciliation.Run, len(dbRuns))
+	for i, dbRun := range dbRuns {
+		runs[i] = convertReconciliationRun(dbRun)
+	}
+
+	return runs, nil
+}
+
+// CreateReconciliationMismatch stores a mismatch found by a run
+func (r *Repository) CreateReconciliationMismatch(ctx context.Context, mismatch *reconciliation.Mismatch) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.CreateReconciliationMismatch")
+	defer span.End()
+
+	params := sqlc.CreateReconciliationMismatchParams{
+		ID:                   mismatch.ID,
+		RunID:                mismatch.RunID,
+		Kind:                 mismatch.Kind,
+		ObjectType:           mismatch.ObjectType,
+		ObjectID:             mismatch.ObjectID,
+		BalanceTransactionID: mismatch.BalanceTransactionID,
+		ProviderAmount:       mismatch.ProviderAmount,
+		LocalAmount:          mismatch.LocalAmount,
+		Currency:             mismatch.Currency,
+		Detail:               mismatch.Detail,
+	}
+
+	if err := r.queries.CreateReconciliationMismatch(ctx, r.db, params); err != nil {
+		return fmt.Errorf("failed to create reconciliation mismatch: %w", err)
+	}
+
+	return nil
+}
+
+// ListReconciliationMismatches retrieves the mismatches found by a run
+func (r *Repository) ListReconciliationMismatches(ctx context.Context, runID string) ([]*reconciliation.Mismatch, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListReconciliationMismatches")
+	defer span.End()
+
+	dbMismatches, err := r.queries.ListReconciliationMismatches(ctx, r.db, runID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reconciliation mismatches: %w", err)
+	}
+
+	mismatches := make([]*reconciliation.Mismatch, len(dbMismatches))
+	for i, dbMismatch := range dbMismatches {
+		mismatches[i] = convertReconciliationMismatch(dbMismatch)
+	}
+
+	return mismatches, nil
+}
+
+// ListCapturedChargesCreatedBetween retrieves the stored captured charges
+// created in [from, to)
+func (r *Repository) ListCapturedChargesCreatedBetween(ctx context.Context, from, to time.Time) ([]*stripe.Charge, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListCapturedChargesCreatedBetween")
+	defer span.End()
+
+	dbCharges, err := r.queries.ListCapturedChargesCreatedBetween(ctx, r.db, sqlc.ListCapturedChargesCreatedBetweenParams{
+		CreatedFrom: sql.NullTime{Time: from, Valid: true},
+		CreatedTo:   sql.NullTime{Time: to, Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list captured charges: %w", err)
+	}
+
+	charges := make([]*stripe.Charge, len(dbCharges))
+	for i, dbCharge := range dbCharges {
+		charges[i] = &stripe.Charge{
+			ID:             dbCharge.ID,
+			Amount:         dbCharge.Amount,
+			AmountDisplay:  money.Describe(dbCharge.Amount, dbCharge.Currency),
+			AmountRefunded: dbCharge.AmountRefunded,
+			Currency:       dbCharge.Currency,
+			Status:         dbCharge.Status,
+			Captured:       dbCharge.Captured,
+			CustomerID:     dbCharge.CustomerID,
+			Created:        dbCharge.CreatedAt.Time.Unix(),
+		}
+	}
+
+	return charges, nil
+}
+
+// ListSucceededRefundsCreatedBetween retrieves the stored succeeded refunds
+// created in [from, to)
+func (r *Repository) ListSucceededRefundsCreatedBetween(ctx context.Context, from, to time.Time) ([]*stripe.Refund, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListSucceededRefundsCreatedBetween")
+	defer span.End()
+
+	dbRefunds, err := r.queries.ListSucceededRefundsCreatedBetween(ctx, r.db, sqlc.ListSucceededRefundsCreatedBetweenParams{
+		CreatedFrom: sql.NullTime{Time: from, Valid: true},
+		CreatedTo:   sql.NullTime{Time: to, Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list succeeded refunds: %w", err)
+	}
+
+	refunds := make([]*stripe.Refund, len(dbRefunds))
+	for i, dbRefund := range dbRefunds {
+		refunds[i] = convertRefund(dbRefund)
+	}
+
+	return refunds, nil
+}
+
+// convertReconciliationRun converts a database reconciliation run to a service run
+func convertReconciliationRun(dbRun sqlc.ReconciliationRun) *reconciliation.Run {
+	run := &reconciliation.Run{
+		ID:                  dbRun.ID,
+		Status:              dbRun.Status,
+		PeriodStart:         dbRun.PeriodStart,
+		PeriodEnd:           dbRun.PeriodEnd,
+		TransactionsChecked: dbRun.TransactionsChecked,
+		PayoutsChecked:      dbRun.PayoutsChecked,
+		Matched:             dbRun.Matched,
+		Mismatches:          dbRun.Mismatches,
+		Error:               dbRun.Error,
+		StartedAt:           dbRun.StartedAt,
+	}
+
+	if dbRun.CompletedAt.Valid {
+		completedAt := dbRun.CompletedAt.Time
+		run.CompletedAt = &completedAt
+	}
+
+	return run
+}
+
+// convertReconciliationMismatch converts a database mismatch to a service mismatch
+func convertReconciliationMismatch(dbMismatch sqlc.ReconciliationMismatch) *reconciliation.Mismatch {
+	return &reconciliation.Mismatch{
+		ID:                   dbMismatch.ID,
+		RunID:                dbMismatch.RunID,
+		Kind:                 dbMismatch.Kind,
+		ObjectType:           dbMismatch.ObjectType,
+		ObjectID:             dbMismatch.ObjectID,
+		BalanceTransactionID: dbMismatch.BalanceTransactionID,
+		ProviderAmount:       dbMismatch.ProviderAmount,
+		LocalAmount:          dbMismatch.LocalAmount,
+		Currency:             dbMismatch.Currency,
+		Detail:               dbMismatch.Detail,
+		CreatedAt:            dbMismatch.CreatedAt.Time,
+	}
+}
@@ -0,0 +1,170 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/refundbatches"
+)
+
+// CreateRefundBatch stores a new pending batch with its refund requests
+func (r *Repository) CreateRefundBatch(ctx context.Context, batch *refundbatches.Batch) (*refundbatches.Batch, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.CreateRefundBatch")
+	defer span.End()
+
+	requests, err := json.Marshal(batch.Requests)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal refund requests: %w", err)
+	}
+
+	dbBatch, err := r.queries.CreateRefundBatch(ctx, r.db, sqlc.CreateRefundBatchParams{
+		ID:          batch.ID,
+		TenantID:    batch.TenantID,
+		ApiKeyID:    batch.APIKeyID,
+		OperatorID:  batch.OperatorID,
+		Requests:    requests,
+		ScheduledAt: batch.ScheduledAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create refund batch: %w", err)
+	}
+
+	return convertRefundBatch(dbBatch), nil
+}
+
+// GetRefundBatch retrieves a batch by ID
+func (r *Repository) GetRefundBatch(ctx context.Context, id string) (*refundbatches.Batch, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetRefundBatch")
+	defer span.End()
+
+	dbBatch, err := r.queries.GetRefundBatch(ctx, r.db, sqlc.GetRefundBatchParams{
+		ID:       id,
+		TenantID: scopedTenant(ctx),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refund batch: %w", err)
+	}
+
+	return convertRefundBatch(dbBatch), nil
+}
+
+// ListRunnableRefundBatches retrieves pending batches scheduled by now and
+// batches left running for over an hour, soonest first
+func (r *Repository) ListRunnableRefundBatches(ctx context.Context, now time.Time, limit int) ([]*refundbatches.Batch, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListRunnableRefundBatches")
+	defer span.End()
+
+	dbBatches, err := r.queries.ListRunnableRefundBatches(ctx, r.db, sqlc.ListRunnableRefundBatchesParams{
+		ScheduledAt: now,
+		Limit:       int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list runnable refund batches: %w", err)
+	}
+
+	batches := make([]*refundbatches.Batch, len(dbBatches))
+	for i, dbBatch := range dbBatches {
+		batches[i] = convertRefundBatch(dbBatch)
+	}
+	return batches, nil
+}
+
+// ClaimRefundBatch marks a runnable batch as running
+func (r *Repository) ClaimRefundBatch(ctx context.Context, id string) (*refundbatches.Batch, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ClaimRefundBatch")
+	defer span.End()
+
+	dbBatch, err := r.queries.ClaimRefundBatch(ctx, r.db, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim refund batch: %w", err)
+	}
+
+	return convertRefundBatch(dbBatch), nil
+}
+
+// FinishRefundBatch moves a batch from one status to a final one
+func (r *Repository) FinishRefundBatch(ctx context.Context, id, from, status string) (*refundbatches.Batch, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.FinishRefundBatch")
+	defer span.End()
+
+	dbBatch, err := r.queries.FinishRefundBatch(ctx, r.db, sqlc.FinishRefundBatchParams{
+		ID:         id,
+		Status:     status,
+		FromStatus: from,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to finish refund batch: %w", err)
+	}
+
+	return convertRefundBatch(dbBatch), nil
+}
+
+// RecordRefundBatchItem stores the result of one refund of a batch
+func (r *Repository) RecordRefundBatchItem(ctx context.Context, batchID string, item *refundbatches.Item) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.RecordRefundBatchItem")
+	defer span.End()
+
+	err := r.queries.RecordRefundBatchItem(ctx, r.db, sqlc.RecordRefundBatchItemParams{
+		BatchID:    batchID,
+		ItemIndex:  int32(item.Index),
+		Status:     item.Status,
+		Amount:     item.Amount,
+		RefundID:   item.RefundID,
+		ApprovalID: item.ApprovalID,
+		Error:      item.Error,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record refund batch item: %w", err)
+	}
+
+	return nil
+}
+
+// ListRefundBatchItems retrieves the recorded results of a batch in order
+func (r *Repository) ListRefundBatchItems(ctx context.Context, batchID string) ([]*refundbatches.Item, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListRefundBatchItems")
+	defer span.End()
+
+	dbItems, err := r.queries.ListRefundBatchItems(ctx, r.db, batchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list refund batch items: %w", err)
+	}
+
+	items := make([]*refundbatches.Item, len(dbItems))
+	for i, dbItem := range dbItems {
+		items[i] = &refundbatches.Item{
+			Index:      int(dbItem.ItemIndex),
+			Status:     dbItem.Status,
+			Amount:     dbItem.Amount,
+			RefundID:   dbItem.RefundID,
+			ApprovalID: dbItem.ApprovalID,
+			Error:      dbItem.Error,
+		}
+	}
+	return items, nil
+}
+
+func convertRefundBatch(dbBatch sqlc.RefundBatch) *refundbatches.Batch {
+	batch := &refundbatches.Batch{
+		ID:          dbBatch.ID,
+		TenantID:    dbBatch.TenantID,
+		APIKeyID:    dbBatch.ApiKeyID,
+		OperatorID:  dbBatch.OperatorID,
+		Status:      dbBatch.Status,
+		ScheduledAt: dbBatch.ScheduledAt,
+		CreatedAt:   dbBatch.CreatedAt.Time,
+		UpdatedAt:   dbBatch.UpdatedAt.Time,
+	}
+	_ = json.Unmarshal(dbBatch.Requests, &batch.Requests)
+	if dbBatch.StartedAt.Valid {
+		batch.StartedAt = &dbBatch.StartedAt.Time
+	}
+	if dbBatch.CompletedAt.Valid {
+		batch.CompletedAt = &dbBatch.CompletedAt.Time
+	}
+
+	return batch
+}
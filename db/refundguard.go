@@ -0,0 +1,219 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/refundguard"
+)
+
+// RecordRefundActivity stores a refund issued by an actor
+func (r *Repository) RecordRefundActivity(ctx context.Context, activity *refundguard.Activity) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.RecordRefundActivity")
+	defer span.End()
+
+	params := sqlc.RecordRefundActivityParams{
+		ID:         activity.ID,
+		TenantID:   activity.TenantID,
+		ApiKeyID:   activity.APIKeyID,
+		OperatorID: activity.OperatorID,
+		RefundID:   activity.RefundID,
+		ChargeID:   activity.ChargeID,
+		Amount:     activity.Amount,
+		Currency:   activity.Currency,
+	}
+
+	if err := r.queries.RecordRefundActivity(ctx, r.db, params); err != nil {
+		return fmt.Errorf("failed to record refund activity: %w", err)
+	}
+
+	return nil
+}
+
+// GetRefundUsage sums the refunds issued along a dimension since the given time
+func (r *Repository) GetRefundUsage(ctx context.Context, dimension, key string, since time.Time) (refundguard.Usage, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetRefundUsage")
+	defer span.End()
+
+	createdAt := sql.NullTime{Time: since, Valid: true}
+
+	var count, amount int64
+	switch dimension {
+	case refundguard.DimensionTenant:
+		row, err := r.queries.GetRefundUsageByTenant(ctx, r.db, sqlc.GetRefundUsageByTenantParams{TenantID: key, CreatedAt: createdAt})
+		if err != nil {
+			return refundguard.Usage{}, fmt.Errorf("failed to get refund usage: %w", err)
+		}
+		count, amount = row.RefundCount, row.RefundAmount
+	case refundguard.DimensionAPIKey:
+		row, err := r.queries.GetRefundUsageByAPIKey(ctx, r.db, sqlc.GetRefundUsageByAPIKeyParams{ApiKeyID: key, CreatedAt: createdAt})
+		if err != nil {
+			return refundguard.Usage{}, fmt.Errorf("failed to get refund usage: %w", err)
+		}
+		count, amount = row.RefundCount, row.RefundAmount
+	case refundguard.DimensionOperator:
+		row, err := r.queries.GetRefundUsageByOperator(ctx, r.db, sqlc.GetRefundUsageByOperatorParams{OperatorID: key, CreatedAt: createdAt})
+		if err != nil {
+			return refundguard.Usage{}, fmt.Errorf("failed to get refund usage: %w", err)
+		}
+		count, amount = row.RefundCount, row.RefundAmount
+	default:
+		return refundguard.Usage{}, fmt.Errorf("unknown refund dimension: %s", dimension)
+	}
+
+	return refundguard.Usage{Count: count, Amount: amount}, nil
+}
+
+// CreateRefundApproval stores a refund held for approval
+func (r *Repository) CreateRefundApproval(ctx context.Context, approval *refundguard.Approval) (*refundguard.Approval, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.CreateRefundApproval")
+	defer span.End()
+
+	request, err := json.Marshal(approval.Request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal refund request: %w", err)
+	}
+
+	reasons, err := json.Marshal(approval.Violations)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal violations: %w", err)
+	}
+
+	params := sqlc.CreateRefundApprovalParams{
+		ID:         approval.ID,
+		TenantID:   approval.TenantID,
+		ApiKeyID:   approval.APIKeyID,
+		OperatorID: approval.OperatorID,
+		Request:    request,
+		Amount:     approval.Amount,
+		Reasons:    reasons,
+		Status:     approval.Status,
+	}
+
+	dbApproval, err := r.queries.CreateRefundApproval(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create refund approval: %w", err)
+	}
+
+	return convertRefundApproval(dbApproval), nil
+}
+
+// GetRefundApproval retrieves a refund approval from the database
+func (r *Repository) GetRefundApproval(ctx context.Context, id string) (*refundguard.Approval, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetRefundApproval")
+	defer span.End()
+
+	dbApproval, err := r.queries.GetRefundApproval(ctx, r.db, sqlc.GetRefundApprovalParams{
+		ID:       id,
+		TenantID: scopedTenant(ctx),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refund approval: %w", err)
+	}
+
+	return convertRefundApproval(dbApproval), nil
+}
+
+// ListPendingRefundApprovals lists the refunds awaiting approval for a tenant
+func (r *Repository) ListPendingRefundApprovals(ctx context.Context, tenantID string) ([]*refundguard.Approval, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListPendingRefundApprovals")
+	defer span.End()
+
+	dbApprovals, err := r.queries.ListPendingRefundApprovals(ctx, r.db, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list refund approvals: %w", err)
+	}
+
+	result := make([]*refundguard.Approval, len(dbApprovals))
+	for i, dbApproval := range dbApprovals {
+		result[i] = convertRefundApproval(dbApproval)
+	}
+
+	return result, nil
+}
+
+// ClaimRefundApproval moves a pending refund approval to approving for the
+// operator approving it, reporting false when it is no longer pending
+func (r *Repository) ClaimRefundApproval(ctx context.Context, id, decidedBy string) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ClaimRefundApproval")
+	defer span.End()
+
+	rows, err := r.queries.ClaimRefundApproval(ctx, r.db, sqlc.ClaimRefundApprovalParams{
+		ID:        id,
+		DecidedBy: sql.NullString{String: decidedBy, Valid: decidedBy != ""},
+		TenantID:  scopedTenant(ctx),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to claim refund approval: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// ReleaseRefundApproval returns a claimed refund approval to pending
+func (r *Repository) ReleaseRefundApproval(ctx context.Context, id string) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.ReleaseRefundApproval")
+	defer span.End()
+
+	err := r.queries.ReleaseRefundApproval(ctx, r.db, sqlc.ReleaseRefundApprovalParams{
+		ID:       id,
+		TenantID: scopedTenant(ctx),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to release refund approval: %w", err)
+	}
+
+	return nil
+}
+
+// DecideRefundApproval records the decision on a refund approval. Approvals
+// complete a claimed approval; rejections decide a pending one.
+func (r *Repository) DecideRefundApproval(ctx context.Context, id, status, decidedBy, refundID string) (*refundguard.Approval, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.DecideRefundApproval")
+	defer span.End()
+
+	params := sqlc.DecideRefundApprovalParams{
+		ID:        id,
+		Status:    status,
+		DecidedBy: sql.NullString{String: decidedBy, Valid: decidedBy != ""},
+		RefundID:  sql.NullString{String: refundID, Valid: refundID != ""},
+		TenantID:  scopedTenant(ctx),
+	}
+
+	dbApproval, err := r.queries.DecideRefundApproval(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decide refund approval: %w", err)
+	}
+
+	return convertRefundApproval(dbApproval), nil
+}
+
+// convertRefundApproval converts a database refund approval to a refundguard.Approval
+func convertRefundApproval(dbApproval sqlc.RefundApproval) *refundguard.Approval {
+	approval := &refundguard.Approval{
+		ID:         dbApproval.ID,
+		TenantID:   dbApproval.TenantID,
+		APIKeyID:   dbApproval.ApiKeyID,
+		OperatorID: dbApproval.OperatorID,
+		Amount:     dbApproval.Amount,
+		Status:     dbApproval.Status,
+		DecidedBy:  dbApproval.DecidedBy.String,
+		RefundID:   dbApproval.RefundID.String,
+		CreatedAt:  dbApproval.CreatedAt.Time,
+		UpdatedAt:  dbApproval.UpdatedAt.Time,
+	}
+
+	_ = json.Unmarshal(dbApproval.Request, &approval.Request)
+	_ = json.Unmarshal(dbApproval.Reasons, &approval.Violations)
+
+	if dbApproval.DecidedAt.Valid {
+		decidedAt := dbApproval.DecidedAt.Time
+		approval.DecidedAt = &decidedAt
+	}
+
+	return approval
+}
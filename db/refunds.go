@@ -0,0 +1,101 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/money"
+	"apis/payments/services/stripe"
+	"apis/payments/services/tenancy"
+)
+
+// UpsertMirroredRefund stores a provider refund unless a newer version is already stored
+func (r *Repository) UpsertMirroredRefund(ctx context.Context, refund *stripe.Refund, syncedAt time.Time) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.UpsertMirroredRefund")
+	defer span.End()
+
+	metadata, err := nullMetadata(refund.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal refund metadata: %w", err)
+	}
+
+	params := sqlc.UpsertMirroredRefundParams{
+		ID:        refund.ID,
+		ChargeID:  refund.ChargeID,
+		Amount:    refund.Amount,
+		Currency:  refund.Currency,
+		Status:    refund.Status,
+		Reason:    sql.NullString{String: refund.Reason, Valid: refund.Reason != ""},
+		Metadata:  metadata,
+		CreatedAt: sql.NullTime{Time: refund.CreatedAt.UTC(), Valid: !refund.CreatedAt.IsZero()},
+		SyncedAt:  syncedAt,
+		TenantID:  tenancy.ID(ctx),
+	}
+
+	if err := r.queries.UpsertMirroredRefund(ctx, r.db, params); err != nil {
+		return fmt.Errorf("failed to upsert refund: %w", err)
+	}
+
+	return nil
+}
+
+// GetRefund retrieves a stored refund by ID
+func (r *Repository) GetRefund(ctx context.Context, refundID string) (*stripe.Refund, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetRefund")
+	defer span.End()
+
+	dbRefund, err := r.queries.GetRefund(ctx, r.db, sqlc.GetRefundParams{
+		ID:       refundID,
+		TenantID: scopedTenant(ctx),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refund: %w", err)
+	}
+
+	return convertRefund(dbRefund), nil
+}
+
+// ListRefunds retrieves the stored refunds of a charge, newest first
+func (r *Repository) ListRefunds(ctx context.Context, chargeID string, limit, offset int32) ([]*stripe.Refund, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListRefunds")
+	defer span.End()
+
+	params := sqlc.ListRefundsParams{
+		ChargeID: chargeID,
+		Limit:    limit,
+		Offset:   offset,
+		TenantID: scopedTenant(ctx),
+	}
+
+	dbRefunds, err := r.queries.ListRefunds(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list refunds: %w", err)
+	}
+
+	refunds := make([]*stripe.Refund, len(dbRefunds))
+	for i, dbRefund := range dbRefunds {
+		refunds[i] = convertRefund(dbRefund)
+	}
+
+	return refunds, nil
+}
+
+// convertRefund converts a database refund to a service refund
+func convertRefund(dbRefund sqlc.Refund) *stripe.Refund {
+	return &stripe.Refund{
+		ID:            dbRefund.ID,
+		ChargeID:      dbRefund.ChargeID,
+		Amount:        dbRefund.Amount,
+		AmountDecimal: money.FormatDecimal(dbRefund.Amount, dbRefund.Currency),
+		AmountDisplay: money.Describe(dbRefund.Amount, dbRefund.Currency),
+		Currency:      dbRefund.Currency,
+		Status:        dbRefund.Status,
+		Reason:        dbRefund.Reason.String,
+		Metadata:      decodeMetadata(dbRefund.Metadata),
+		CreatedAt:     dbRefund.CreatedAt.Time,
+		UpdatedAt:     dbRefund.UpdatedAt.Time,
+	}
+}
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/events"
+	"apis/payments/services/relay"
+)
+
+// InsertOutboxEvent stores a relayed event, ignoring events already stored
+func (r *Repository) InsertOutboxEvent(ctx context.Context, event *relay.OutboxEvent) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.InsertOutboxEvent")
+	defer span.End()
+
+	payload, err := json.Marshal(event.Event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	err = r.queries.InsertOutboxEvent(ctx, r.db, sqlc.InsertOutboxEventParams{
+		ID:       event.Event.ID,
+		TenantID: event.TenantID,
+		Payload:  payload,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to insert outbox event: %w", err)
+	}
+
+	return nil
+}
+
+// ListOutboxEvents lists stored relayed events, oldest first
+func (r *Repository) ListOutboxEvents(ctx context.Context, limit int) ([]*relay.OutboxEvent, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListOutboxEvents")
+	defer span.End()
+
+	dbEvents, err := r.queries.ListOutboxEvents(ctx, r.db, int32(limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list outbox events: %w", err)
+	}
+
+	outboxEvents := make([]*relay.OutboxEvent, len(dbEvents))
+	for i, dbEvent := range dbEvents {
+		var event events.Event
+		if err := json.Unmarshal(dbEvent.Payload, &event); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal outbox event %s: %w", dbEvent.ID, err)
+		}
+		outboxEvents[i] = &relay.OutboxEvent{Event: &event, TenantID: dbEvent.TenantID}
+	}
+	return outboxEvents, nil
+}
+
+// DeleteOutboxEvent removes a published event from the outbox
+func (r *Repository) DeleteOutboxEvent(ctx context.Context, id string) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.DeleteOutboxEvent")
+	defer span.End()
+
+	if err := r.queries.DeleteOutboxEvent(ctx, r.db, id); err != nil {
+		return fmt.Errorf("failed to delete outbox event: %w", err)
+	}
+
+	return nil
+}
@@ -6,9 +6,11 @@ import (
 	"fmt"
 
 	"apis/payments/db/sqlc"
+	"apis/payments/services/money"
 	"apis/payments/services/stripe"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/trace"
 )
@@ -17,14 +19,16 @@ import (
 type Repository struct {
 	queries *sqlc.Queries
 	pool    *pgxpool.Pool
+	db      sqlc.DBTX
 	tracer  trace.Tracer
 }
 
 // NewRepository creates a new repository instance
 func NewRepository(pool *pgxpool.Pool) *Repository {
 	return &Repository{
-		queries: sqlc.New(pool),
+		queries: sqlc.New(),
 		pool:    pool,
+		db:      stdlib.OpenDBFromPool(pool),
 		tracer:  otel.Tracer("payments.repository"),
 	}
 }
@@ -34,10 +38,9 @@ func (r *Repository) CreateCustomer(ctx context.Context, customer *stripe.Custom
 	ctx, span := r.tracer.Start(ctx, "Repository.CreateCustomer")
 	defer span.End()
 
-	// Convert metadata to JSON string for storage
-	metadata := make(map[string]interface{})
-	for k, v := range customer.Metadata {
-		metadata[k] = v
+	metadata, err := nullMetadata(customer.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal customer metadata: %w", err)
 	}
 
 	params := sqlc.CreateCustomerParams{
@@ -49,7 +52,7 @@ func (r *Repository) CreateCustomer(ctx context.Context, customer *stripe.Custom
 		Metadata:    metadata,
 	}
 
-	dbCustomer, err := r.queries.CreateCustomer(ctx, params)
+	dbCustomer, err := r.queries.CreateCustomer(ctx, r.db, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create customer: %w", err)
 	}
@@ -61,9 +64,9 @@ func (r *Repository) CreateCustomer(ctx context.Context, customer *stripe.Custom
 		Name:        dbCustomer.Name,
 		Phone:       dbCustomer.Phone.String,
 		Description: dbCustomer.Description.String,
-		Metadata:    convertMetadata(dbCustomer.Metadata),
-		Created:     dbCustomer.CreatedAt.Unix(),
-		Updated:     dbCustomer.UpdatedAt.Unix(),
+		Metadata:    decodeMetadata(dbCustomer.Metadata),
+		Created:     dbCustomer.CreatedAt.Time.Unix(),
+		Updated:     dbCustomer.UpdatedAt.Time.Unix(),
 	}, nil
 }
 
@@ -72,7 +75,10 @@ func (r *Repository) GetCustomer(ctx context.Context, id string) (*stripe.Custom
 	ctx, span := r.tracer.Start(ctx, "Repository.GetCustomer")
 	defer span.End()
 
-	dbCustomer, err := r.queries.GetCustomer(ctx, id)
+	dbCustomer, err := r.queries.GetCustomer(ctx, r.db, sqlc.GetCustomerParams{
+		ID:       id,
+		TenantID: scopedTenant(ctx),
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get customer: %w", err)
 	}
@@ -83,9 +89,9 @@ func (r *Repository) GetCustomer(ctx context.Context, id string) (*stripe.Custom
 		Name:        dbCustomer.Name,
 		Phone:       dbCustomer.Phone.String,
 		Description: dbCustomer.Description.String,
-		Metadata:    convertMetadata(dbCustomer.Metadata),
-		Created:     dbCustomer.CreatedAt.Unix(),
-		Updated:     dbCustomer.UpdatedAt.Unix(),
+		Metadata:    decodeMetadata(dbCustomer.Metadata),
+		Created:     dbCustomer.CreatedAt.Time.Unix(),
+		Updated:     dbCustomer.UpdatedAt.Time.Unix(),
 	}, nil
 }
 
@@ -94,10 +100,9 @@ func (r *Repository) UpdateCustomer(ctx context.Context, id string, customer *st
 	ctx, span := r.tracer.Start(ctx, "Repository.UpdateCustomer")
 	defer span.End()
 
-	// Convert metadata to JSON string for storage
-	metadata := make(map[string]interface{})
-	for k, v := range customer.Metadata {
-		metadata[k] = v
+	metadata, err := nullMetadata(customer.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal customer metadata: %w", err)
 	}
 
 	params := sqlc.UpdateCustomerParams{
@@ -109,7 +114,7 @@ func (r *Repository) UpdateCustomer(ctx context.Context, id string, customer *st
 		Metadata:    metadata,
 	}
 
-	dbCustomer, err := r.queries.UpdateCustomer(ctx, params)
+	dbCustomer, err := r.queries.UpdateCustomer(ctx, r.db, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update customer: %w", err)
 	}
@@ -120,9 +125,9 @@ func (r *Repository) UpdateCustomer(ctx context.Context, id string, customer *st
 		Name:        dbCustomer.Name,
 		Phone:       dbCustomer.Phone.String,
 		Description: dbCustomer.Description.String,
-		Metadata:    convertMetadata(dbCustomer.Metadata),
-		Created:     dbCustomer.CreatedAt.Unix(),
-		Updated:     dbCustomer.UpdatedAt.Unix(),
+		Metadata:    decodeMetadata(dbCustomer.Metadata),
+		Created:     dbCustomer.CreatedAt.Time.Unix(),
+		Updated:     dbCustomer.UpdatedAt.Time.Unix(),
 	}, nil
 }
 
@@ -131,7 +136,7 @@ func (r *Repository) DeleteCustomer(ctx context.Context, id string) error {
 	ctx, span := r.tracer.Start(ctx, "Repository.DeleteCustomer")
 	defer span.End()
 
-	err := r.queries.DeleteCustomer(ctx, id)
+	err := r.queries.DeleteCustomer(ctx, r.db, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete customer: %w", err)
 	}
@@ -144,10 +149,9 @@ func (r *Repository) StorePaymentMethod(ctx context.Context, paymentMethod *stri
 	ctx, span := r.tracer.Start(ctx, "Repository.StorePaymentMethod")
 	defer span.End()
 
-	// Convert metadata to JSON string for storage
-	metadata := make(map[string]interface{})
-	for k, v := range paymentMethod.Metadata {
-		metadata[k] = v
+	metadata, err := nullMetadata(paymentMethod.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payment method metadata: %w", err)
 	}
 
 	var cardLast4, cardBrand, cardFingerprint sql.NullString
@@ -173,7 +177,7 @@ func (r *Repository) StorePaymentMethod(ctx context.Context, paymentMethod *stri
 		Metadata:        metadata,
 	}
 
-	dbPaymentMethod, err := r.queries.CreatePaymentMethod(ctx, params)
+	dbPaymentMethod, err := r.queries.CreatePaymentMethod(ctx, r.db, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to store payment method: %w", err)
 	}
@@ -183,8 +187,8 @@ func (r *Repository) StorePaymentMethod(ctx context.Context, paymentMethod *stri
 		ID:       dbPaymentMethod.ID,
 		Type:     dbPaymentMethod.Type,
 		Customer: dbPaymentMethod.CustomerID,
-		Metadata: convertMetadata(dbPaymentMethod.Metadata),
-		Created:  dbPaymentMethod.CreatedAt.Unix(),
+		Metadata: decodeMetadata(dbPaymentMethod.Metadata),
+		Created:  dbPaymentMethod.CreatedAt.Time.Unix(),
 	}
 
 	// Add card details if available
@@ -206,7 +210,10 @@ func (r *Repository) GetPaymentMethod(ctx context.Context, id string) (*stripe.P
 	ctx, span := r.tracer.Start(ctx, "Repository.GetPaymentMethod")
 	defer span.End()
 
-	dbPaymentMethod, err := r.queries.GetPaymentMethod(ctx, id)
+	dbPaymentMethod, err := r.queries.GetPaymentMethod(ctx, r.db, sqlc.GetPaymentMethodParams{
+		ID:       id,
+		TenantID: scopedTenant(ctx),
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get payment method: %w", err)
 	}
@@ -215,8 +222,8 @@ func (r *Repository) GetPaymentMethod(ctx context.Context, id string) (*stripe.P
 		ID:       dbPaymentMethod.ID,
 		Type:     dbPaymentMethod.Type,
 		Customer: dbPaymentMethod.CustomerID,
-		Metadata: convertMetadata(dbPaymentMethod.Metadata),
-		Created:  dbPaymentMethod.CreatedAt.Unix(),
+		Metadata: decodeMetadata(dbPaymentMethod.Metadata),
+		Created:  dbPaymentMethod.CreatedAt.Time.Unix(),
 	}
 
 	// Add card details if available
@@ -238,7 +245,10 @@ func (r *Repository) ListPaymentMethods(ctx context.Context, customerID string)
 	ctx, span := r.tracer.Start(ctx, "Repository.ListPaymentMethods")
 	defer span.End()
 
-	dbPaymentMethods, err := r.queries.ListPaymentMethods(ctx, customerID)
+	dbPaymentMethods, err := r.queries.ListPaymentMethods(ctx, r.db, sqlc.ListPaymentMethodsParams{
+		CustomerID: customerID,
+		TenantID:   scopedTenant(ctx),
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list payment methods: %w", err)
 	}
@@ -249,8 +259,8 @@ func (r *Repository) ListPaymentMethods(ctx context.Context, customerID string)
 			ID:       dbPM.ID,
 			Type:     dbPM.Type,
 			Customer: dbPM.CustomerID,
-			Metadata: convertMetadata(dbPM.Metadata),
-			Created:  dbPM.CreatedAt.Unix(),
+			Metadata: decodeMetadata(dbPM.Metadata),
+			Created:  dbPM.CreatedAt.Time.Unix(),
 		}
 
 		// Add card details if available
@@ -275,7 +285,7 @@ func (r *Repository) DeletePaymentMethod(ctx context.Context, id, customerID str
 	ctx, span := r.tracer.Start(ctx, "Repository.DeletePaymentMethod")
 	defer span.End()
 
-	err := r.queries.DeletePaymentMethod(ctx, sqlc.DeletePaymentMethodParams{
+	err := r.queries.DeletePaymentMethod(ctx, r.db, sqlc.DeletePaymentMethodParams{
 		ID:         id,
 		CustomerID: customerID,
 	})
@@ -291,10 +301,9 @@ func (r *Repository) StoreCharge(ctx context.Context, charge *stripe.Charge) (*s
 	ctx, span := r.tracer.Start(ctx, "Repository.StoreCharge")
 	defer span.End()
 
-	// Convert metadata to JSON string for storage
-	metadata := make(map[string]interface{})
-	for k, v := range charge.Metadata {
-		metadata[k] = v
+	metadata, err := nullMetadata(charge.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal charge metadata: %w", err)
 	}
 
 	params := sqlc.CreateChargeParams{
@@ -308,7 +317,7 @@ func (r *Repository) StoreCharge(ctx context.Context, charge *stripe.Charge) (*s
 		Metadata:        metadata,
 	}
 
-	dbCharge, err := r.queries.CreateCharge(ctx, params)
+	dbCharge, err := r.queries.CreateCharge(ctx, r.db, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to store charge: %w", err)
 	}
@@ -316,13 +325,14 @@ func (r *Repository) StoreCharge(ctx context.Context, charge *stripe.Charge) (*s
 	return &stripe.Charge{
 		ID:              dbCharge.ID,
 		Amount:          dbCharge.Amount,
+		AmountDisplay:   money.Describe(dbCharge.Amount, dbCharge.Currency),
 		Currency:        dbCharge.Currency,
 		Status:          dbCharge.Status,
 		CustomerID:      dbCharge.CustomerID,
 		PaymentMethodID: dbCharge.PaymentMethodID.String,
 		Description:     dbCharge.Description.String,
-		Metadata:        convertMetadata(dbCharge.Metadata),
-		Created:         dbCharge.CreatedAt.Unix(),
+		Metadata:        decodeMetadata(dbCharge.Metadata),
+		Created:         dbCharge.CreatedAt.Time.Unix(),
 	}, nil
 }
 
@@ -331,7 +341,10 @@ func (r *Repository) GetCharge(ctx context.Context, id string) (*stripe.Charge,
 	ctx, span := r.tracer.Start(ctx, "Repository.GetCharge")
 	defer span.End()
 
-	dbCharge, err := r.queries.GetCharge(ctx, id)
+	dbCharge, err := r.queries.GetCharge(ctx, r.db, sqlc.GetChargeParams{
+		ID:       id,
+		TenantID: scopedTenant(ctx),
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get charge: %w", err)
 	}
@@ -339,13 +352,14 @@ func (r *Repository) GetCharge(ctx context.Context, id string) (*stripe.Charge,
 	return &stripe.Charge{
 		ID:              dbCharge.ID,
 		Amount:          dbCharge.Amount,
+		AmountDisplay:   money.Describe(dbCharge.Amount, dbCharge.Currency),
 		Currency:        dbCharge.Currency,
 		Status:          dbCharge.Status,
 		CustomerID:      dbCharge.CustomerID,
 		PaymentMethodID: dbCharge.PaymentMethodID.String,
 		Description:     dbCharge.Description.String,
-		Metadata:        convertMetadata(dbCharge.Metadata),
-		Created:         dbCharge.CreatedAt.Unix(),
+		Metadata:        decodeMetadata(dbCharge.Metadata),
+		Created:         dbCharge.CreatedAt.Time.Unix(),
 	}, nil
 }
 
@@ -354,10 +368,11 @@ func (r *Repository) ListCharges(ctx context.Context, customerID string, limit,
 	ctx, span := r.tracer.Start(ctx, "Repository.ListCharges")
 	defer span.End()
 
-	dbCharges, err := r.queries.ListCharges(ctx, sqlc.ListChargesParams{
+	dbCharges, err := r.queries.ListCharges(ctx, r.db, sqlc.ListChargesParams{
 		CustomerID: customerID,
 		Limit:      limit,
 		Offset:     offset,
+		TenantID:   scopedTenant(ctx),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list charges: %w", err)
@@ -368,31 +383,17 @@ func (r *Repository) ListCharges(ctx context.Context, customerID string, limit,
 		charge := &stripe.Charge{
 			ID:              dbCharge.ID,
 			Amount:          dbCharge.Amount,
+			AmountDisplay:   money.Describe(dbCharge.Amount, dbCharge.Currency),
 			Currency:        dbCharge.Currency,
 			Status:          dbCharge.Status,
 			CustomerID:      dbCharge.CustomerID,
 			PaymentMethodID: dbCharge.PaymentMethodID.String,
 			Description:     dbCharge.Description.String,
-			Metadata:        convertMetadata(dbCharge.Metadata),
-			Created:         dbCharge.CreatedAt.Unix(),
+			Metadata:        decodeMetadata(dbCharge.Metadata),
+			Created:         dbCharge.CreatedAt.Time.Unix(),
 		}
 		result = append(result, charge)
 	}
 
 	return result, nil
 }
-
-// convertMetadata converts database metadata to stripe metadata format
-func convertMetadata(dbMetadata map[string]interface{}) map[string]string {
-	if dbMetadata == nil {
-		return nil
-	}
-
-	result := make(map[string]string)
-	for k, v := range dbMetadata {
-		if str, ok := v.(string); ok {
-			result[k] = str
-		}
-	}
-	return result
-}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/routing"
+)
+
+// RecordRoutedCharge stores the routing decision made for a charge
+func (r *Repository) RecordRoutedCharge(ctx context.Context, charge *routing.RoutedCharge) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.RecordRoutedCharge")
+	defer span.End()
+
+	params := sqlc.RecordRoutedChargeParams{
+		ChargeID:           charge.ChargeID,
+		Provider:           charge.Provider,
+		Currency:           charge.Currency,
+		Amount:             charge.Amount,
+		SettlementCurrency: charge.SettlementCurrency,
+		Reason:             charge.Reason,
+	}
+
+	if err := r.queries.RecordRoutedCharge(ctx, r.db, params); err != nil {
+		return fmt.Errorf("failed to record routed charge: %w", err)
+	}
+
+	return nil
+}
+
+// SummarizeRoutedCharges totals routed charges per provider and currency
+func (r *Repository) SummarizeRoutedCharges(ctx context.Context, from, to time.Time) ([]*routing.Volume, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.SummarizeRoutedCharges")
+	defer span.End()
+
+	params := sqlc.SummarizeRoutedChargesParams{
+		CreatedFrom: sql.NullTime{Time: from, Valid: true},
+		CreatedTo:   sql.NullTime{Time: to, Valid: true},
+	}
+
+	rows, err := r.queries.SummarizeRoutedCharges(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize routed charges: %w", err)
+	}
+
+	volumes := make([]*routing.Volume, len(rows))
+	for i, row := range rows {
+		volumes[i] = &routing.Volume{
+			Provider:           row.Provider,
+			Currency:           row.Currency,
+			SettlementCurrency: row.SettlementCurrency,
+			Count:              row.ChargeCount,
+			Amount:             row.TotalAmount,
+		}
+	}
+
+	return volumes, nil
+}
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/simulator"
+)
+
+// SaveSimulatorObject creates or replaces a simulator object
+func (r *Repository) SaveSimulatorObject(ctx context.Context, object *simulator.Object) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.SaveSimulatorObject")
+	defer span.End()
+
+	params := sqlc.SaveSimulatorObjectParams{
+		TenantID: object.TenantID,
+		ID:       object.ID,
+		Kind:     object.Kind,
+		ParentID: object.ParentID,
+		Data:     object.Data,
+	}
+
+	if err := r.queries.SaveSimulatorObject(ctx, r.db, params); err != nil {
+		return fmt.Errorf("failed to save simulator object: %w", err)
+	}
+
+	return nil
+}
+
+// GetSimulatorObject retrieves a tenant's simulator object
+func (r *Repository) GetSimulatorObject(ctx context.Context, tenantID, kind, id string) (*simulator.Object, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetSimulatorObject")
+	defer span.End()
+
+	params := sqlc.GetSimulatorObjectParams{
+		TenantID: tenantID,
+		Kind:     kind,
+		ID:       id,
+	}
+
+	dbObject, err := r.queries.GetSimulatorObject(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get simulator object: %w", err)
+	}
+
+	return convertSimulatorObject(dbObject), nil
+}
+
+// ListSimulatorObjects retrieves a page of a tenant's simulator objects of a
+// kind, newest first
+func (r *Repository) ListSimulatorObjects(ctx context.Context, tenantID, kind, parentID, cursor string, limit int) ([]*simulator.Object, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListSimulatorObjects")
+	defer span.End()
+
+	params := sqlc.ListSimulatorObjectsParams{
+		TenantID: tenantID,
+		Kind:     kind,
+		ParentID: parentID,
+		Cursor:   cursor,
+		Limit:    int32(limit),
+	}
+
+	dbObjects, err := r.queries.ListSimulatorObjects(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list simulator objects: %w", err)
+	}
+
+	objects := make([]*simulator.Object, len(dbObjects))
+	for i, dbObject := range dbObjects {
+		objects[i] = convertSimulatorObject(dbObject)
+	}
+	return objects, nil
+}
+
+// DeleteSimulatorObject deletes a tenant's simulator object, reporting
+// whether it existed
+func (r *Repository) DeleteSimulatorObject(ctx context.Context, tenantID, kind, id string) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.DeleteSimulatorObject")
+	defer span.End()
+
+	params := sqlc.DeleteSimulatorObjectParams{
+		TenantID: tenantID,
+		Kind:     kind,
+		ID:       id,
+	}
+
+	rows, err := r.queries.DeleteSimulatorObject(ctx, r.db, params)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete simulator object: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// convertSimulatorObject converts a database simulator object
+func convertSimulatorObject(dbObject sqlc.SimulatorObject) *simulator.Object {
+	return &simulator.Object{
+		TenantID:  dbObject.TenantID,
+		ID:        dbObject.ID,
+		Kind:      dbObject.Kind,
+		ParentID:  dbObject.ParentID,
+		Data:      dbObject.Data,
+		CreatedAt: dbObject.CreatedAt,
+	}
+}
@@ -0,0 +1,141 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/smartrouting"
+)
+
+// CreateSmartRoutingRule stores a new routing rule override
+func (r *Repository) CreateSmartRoutingRule(ctx context.Context, rule *smartrouting.Rule) (*smartrouting.Rule, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.CreateSmartRoutingRule")
+	defer span.End()
+
+	providers, err := json.Marshal(rule.Providers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal rule providers: %w", err)
+	}
+
+	params := sqlc.CreateSmartRoutingRuleParams{
+		ID:             rule.ID,
+		Currency:       rule.Currency,
+		BinCountry:     rule.BINCountry,
+		MinAmount:      rule.MinAmount,
+		MaxAmount:      rule.MaxAmount,
+		Strategy:       rule.Strategy,
+		Providers:      providers,
+		PinnedProvider: rule.PinnedProvider,
+		Priority:       int32(rule.Priority),
+		Enabled:        rule.Enabled,
+		Description:    rule.Description,
+		UpdatedBy:      rule.UpdatedBy,
+	}
+
+	dbRule, err := r.queries.CreateSmartRoutingRule(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create smart routing rule: %w", err)
+	}
+
+	return convertSmartRoutingRule(dbRule), nil
+}
+
+// GetSmartRoutingRule retrieves a routing rule override
+func (r *Repository) GetSmartRoutingRule(ctx context.Context, id string) (*smartrouting.Rule, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetSmartRoutingRule")
+	defer span.End()
+
+	dbRule, err := r.queries.GetSmartRoutingRule(ctx, r.db, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get smart routing rule: %w", err)
+	}
+
+	return convertSmartRoutingRule(dbRule), nil
+}
+
+// ListSmartRoutingRules retrieves every routing rule override in priority order
+func (r *Repository) ListSmartRoutingRules(ctx context.Context) ([]*smartrouting.Rule, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListSmartRoutingRules")
+	defer span.End()
+
+	dbRules, err := r.queries.ListSmartRoutingRules(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list smart routing rules: %w", err)
+	}
+
+	rules := make([]*smartrouting.Rule, len(dbRules))
+	for i, dbRule := range dbRules {
+		rules[i] = convertSmartRoutingRule(dbRule)
+	}
+
+	return rules, nil
+}
+
+// UpdateSmartRoutingRule replaces a routing rule override
+func (r *Repository) UpdateSmartRoutingRule(ctx context.Context, rule *smartrouting.Rule) (*smartrouting.Rule, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.UpdateSmartRoutingRule")
+	defer span.End()
+
+	providers, err := json.Marshal(rule.Providers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal rule providers: %w", err)
+	}
+
+	params := sqlc.UpdateSmartRoutingRuleParams{
+		ID:             rule.ID,
+		Currency:       rule.Currency,
+		BinCountry:     rule.BINCountry,
+		MinAmount:      rule.MinAmount,
+		MaxAmount:      rule.MaxAmount,
+		Strategy:       rule.Strategy,
+		Providers:      providers,
+		PinnedProvider: rule.PinnedProvider,
+		Priority:       int32(rule.Priority),
+		Enabled:        rule.Enabled,
+		Description:    rule.Description,
+		UpdatedBy:      rule.UpdatedBy,
+	}
+
+	dbRule, err := r.queries.UpdateSmartRoutingRule(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update smart routing rule: %w", err)
+	}
+
+	return convertSmartRoutingRule(dbRule), nil
+}
+
+// DeleteSmartRoutingRule removes a routing rule override, reporting whether it existed
+func (r *Repository) DeleteSmartRoutingRule(ctx context.Context, id string) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.DeleteSmartRoutingRule")
+	defer span.End()
+
+	rows, err := r.queries.DeleteSmartRoutingRule(ctx, r.db, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete smart routing rule: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// convertSmartRoutingRule converts a database routing rule to a service rule
+func convertSmartRoutingRule(dbRule sqlc.SmartRoutingRule) *smartrouting.Rule {
+	rule := &smartrouting.Rule{
+		ID:             dbRule.ID,
+		Currency:       dbRule.Currency,
+		BINCountry:     dbRule.BinCountry,
+		MinAmount:      dbRule.MinAmount,
+		MaxAmount:      dbRule.MaxAmount,
+		Strategy:       dbRule.Strategy,
+		PinnedProvider: dbRule.PinnedProvider,
+		Priority:       int(dbRule.Priority),
+		Enabled:        dbRule.Enabled,
+		Description:    dbRule.Description,
+		UpdatedBy:      dbRule.UpdatedBy,
+		CreatedAt:      dbRule.CreatedAt.Time,
+		UpdatedAt:      dbRule.UpdatedAt.Time,
+	}
+	_ = json.Unmarshal(dbRule.Providers, &rule.Providers)
+	return rule
+}
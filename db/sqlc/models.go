@@ -6,10 +6,94 @@ package sqlc
 
 import (
 	"database/sql"
+	"encoding/json"
+	"time"
 
 	"github.com/sqlc-dev/pqtype"
 )
 
+type ApiKey struct {
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	SecretHash  string          `json:"secret_hash"`
+	SecretHint  string          `json:"secret_hint"`
+	Scopes      json.RawMessage `json:"scopes"`
+	TenantID    string          `json:"tenant_id"`
+	CreatedBy   string          `json:"created_by"`
+	RotatedFrom string          `json:"rotated_from"`
+	ExpiresAt   sql.NullTime    `json:"expires_at"`
+	RevokedAt   sql.NullTime    `json:"revoked_at"`
+	CreatedAt   sql.NullTime    `json:"created_at"`
+}
+
+type AuditLog struct {
+	Sequence     int64                 `json:"sequence"`
+	TenantID     string                `json:"tenant_id"`
+	ActorType    string                `json:"actor_type"`
+	ActorID      string                `json:"actor_id"`
+	OperatorID   string                `json:"operator_id"`
+	Action       string                `json:"action"`
+	ResourceType string                `json:"resource_type"`
+	ResourceID   string                `json:"resource_id"`
+	Method       string                `json:"method"`
+	Path         string                `json:"path"`
+	Status       int32                 `json:"status"`
+	BeforeState  pqtype.NullRawMessage `json:"before_state"`
+	AfterState   pqtype.NullRawMessage `json:"after_state"`
+	Changes      json.RawMessage       `json:"changes"`
+	Ip           string                `json:"ip"`
+	RequestID    string                `json:"request_id"`
+	CreatedAt    time.Time             `json:"created_at"`
+	PreviousHash string                `json:"previous_hash"`
+	Hash         string                `json:"hash"`
+}
+
+type Authorization struct {
+	ChargeID       string       `json:"charge_id"`
+	TenantID       string       `json:"tenant_id"`
+	Provider       string       `json:"provider"`
+	Amount         int64        `json:"amount"`
+	AmountCaptured int64        `json:"amount_captured"`
+	Currency       string       `json:"currency"`
+	Captures       int32        `json:"captures"`
+	MultiCapture   bool         `json:"multi_capture"`
+	Status         string       `json:"status"`
+	ExpiresAt      time.Time    `json:"expires_at"`
+	ClosedAt       sql.NullTime `json:"closed_at"`
+	CreatedAt      sql.NullTime `json:"created_at"`
+	UpdatedAt      sql.NullTime `json:"updated_at"`
+}
+
+type AutoRefund struct {
+	ID            string       `json:"id"`
+	CustomerID    string       `json:"customer_id"`
+	Currency      string       `json:"currency"`
+	Amount        int64        `json:"amount"`
+	RefundID      string       `json:"refund_id"`
+	Status        string       `json:"status"`
+	FailureReason string       `json:"failure_reason"`
+	FundedAt      time.Time    `json:"funded_at"`
+	CreatedAt     sql.NullTime `json:"created_at"`
+}
+
+type AutoRefundExclusion struct {
+	CustomerID string       `json:"customer_id"`
+	Reason     string       `json:"reason"`
+	CreatedAt  sql.NullTime `json:"created_at"`
+}
+
+type BlocklistEntry struct {
+	ID             string       `json:"id"`
+	EntryType      string       `json:"entry_type"`
+	Value          string       `json:"value"`
+	Reason         string       `json:"reason"`
+	Source         string       `json:"source"`
+	CreatedBy      string       `json:"created_by"`
+	ProviderItemID string       `json:"provider_item_id"`
+	CreatedAt      sql.NullTime `json:"created_at"`
+	UpdatedAt      sql.NullTime `json:"updated_at"`
+}
+
 type Charge struct {
 	ID              string                `json:"id"`
 	Amount          int64                 `json:"amount"`
@@ -21,6 +105,109 @@ type Charge struct {
 	Metadata        pqtype.NullRawMessage `json:"metadata"`
 	CreatedAt       sql.NullTime          `json:"created_at"`
 	UpdatedAt       sql.NullTime          `json:"updated_at"`
+	AmountRefunded  int64                 `json:"amount_refunded"`
+	Captured        bool                  `json:"captured"`
+	Refunded        bool                  `json:"refunded"`
+	Disputed        bool                  `json:"disputed"`
+	InvoiceID       string                `json:"invoice_id"`
+	SyncedAt        time.Time             `json:"synced_at"`
+	TenantID        string                `json:"tenant_id"`
+}
+
+type ChargeCredential struct {
+	ChargeID        string       `json:"charge_id"`
+	TenantID        string       `json:"tenant_id"`
+	CustomerID      string       `json:"customer_id"`
+	PaymentMethodID string       `json:"payment_method_id"`
+	Network         string       `json:"network"`
+	CredentialType  string       `json:"credential_type"`
+	Wallet          string       `json:"wallet"`
+	Amount          int64        `json:"amount"`
+	Currency        string       `json:"currency"`
+	Status          string       `json:"status"`
+	FailureCode     string       `json:"failure_code"`
+	CreatedAt       sql.NullTime `json:"created_at"`
+	UpdatedAt       sql.NullTime `json:"updated_at"`
+}
+
+type ChargeListRow struct {
+	ChargeID         string       `json:"charge_id"`
+	TenantID         string       `json:"tenant_id"`
+	CustomerID       string       `json:"customer_id"`
+	CustomerEmail    string       `json:"customer_email"`
+	CustomerName     string       `json:"customer_name"`
+	PlanName         string       `json:"plan_name"`
+	Amount           int64        `json:"amount"`
+	AmountRefunded   int64        `json:"amount_refunded"`
+	Currency         string       `json:"currency"`
+	Status           string       `json:"status"`
+	Description      string       `json:"description"`
+	LastRefundID     string       `json:"last_refund_id"`
+	LastRefundStatus string       `json:"last_refund_status"`
+	ChargeCreated    time.Time    `json:"charge_created"`
+	CreatedAt        sql.NullTime `json:"created_at"`
+	UpdatedAt        sql.NullTime `json:"updated_at"`
+}
+
+type ChargeTransition struct {
+	ID        string       `json:"id"`
+	ChargeID  string       `json:"charge_id"`
+	Sequence  int32        `json:"sequence"`
+	FromState string       `json:"from_state"`
+	ToState   string       `json:"to_state"`
+	Source    string       `json:"source"`
+	EventID   string       `json:"event_id"`
+	CreatedAt sql.NullTime `json:"created_at"`
+}
+
+type CompositeCharge struct {
+	ID             string       `json:"id"`
+	TenantID       string       `json:"tenant_id"`
+	Amount         int64        `json:"amount"`
+	Currency       string       `json:"currency"`
+	RefundStrategy string       `json:"refund_strategy"`
+	CreatedAt      sql.NullTime `json:"created_at"`
+	UpdatedAt      sql.NullTime `json:"updated_at"`
+}
+
+type CompositeChargeLeg struct {
+	CompositeChargeID string `json:"composite_charge_id"`
+	ChargeID          string `json:"charge_id"`
+	Position          int32  `json:"position"`
+	Priority          int32  `json:"priority"`
+	Amount            int64  `json:"amount"`
+}
+
+type CompositeRefund struct {
+	ID                string       `json:"id"`
+	CompositeChargeID string       `json:"composite_charge_id"`
+	TenantID          string       `json:"tenant_id"`
+	Amount            int64        `json:"amount"`
+	Currency          string       `json:"currency"`
+	Reason            string       `json:"reason"`
+	Status            string       `json:"status"`
+	CreatedAt         sql.NullTime `json:"created_at"`
+	UpdatedAt         sql.NullTime `json:"updated_at"`
+}
+
+type CompositeRefundLeg struct {
+	CompositeRefundID string       `json:"composite_refund_id"`
+	ChargeID          string       `json:"charge_id"`
+	Position          int32        `json:"position"`
+	Amount            int64        `json:"amount"`
+	RefundID          string       `json:"refund_id"`
+	ApprovalID        string       `json:"approval_id"`
+	Status            string       `json:"status"`
+	FailureReason     string       `json:"failure_reason"`
+	CreatedAt         sql.NullTime `json:"created_at"`
+	UpdatedAt         sql.NullTime `json:"updated_at"`
+}
+
+type CustomFieldDefinition struct {
+	TenantID  string          `json:"tenant_id"`
+	Fields    json.RawMessage `json:"fields"`
+	CreatedAt sql.NullTime    `json:"created_at"`
+	UpdatedAt sql.NullTime    `json:"updated_at"`
 }
 
 type Customer struct {
@@ -32,6 +219,453 @@ type Customer struct {
 	Metadata    pqtype.NullRawMessage `json:"metadata"`
 	CreatedAt   sql.NullTime          `json:"created_at"`
 	UpdatedAt   sql.NullTime          `json:"updated_at"`
+	SyncedAt    time.Time             `json:"synced_at"`
+	TenantID    string                `json:"tenant_id"`
+}
+
+type CustomerBalance struct {
+	TenantID   string       `json:"tenant_id"`
+	CustomerID string       `json:"customer_id"`
+	Currency   string       `json:"currency"`
+	Amount     int64        `json:"amount"`
+	CreatedAt  sql.NullTime `json:"created_at"`
+	UpdatedAt  sql.NullTime `json:"updated_at"`
+}
+
+type CustomerBalanceTransaction struct {
+	ID                    string       `json:"id"`
+	TenantID              string       `json:"tenant_id"`
+	CustomerID            string       `json:"customer_id"`
+	Type                  string       `json:"type"`
+	Reason                string       `json:"reason"`
+	Amount                int64        `json:"amount"`
+	Currency              string       `json:"currency"`
+	BalanceAfter          int64        `json:"balance_after"`
+	Description           string       `json:"description"`
+	SourceID              string       `json:"source_id"`
+	ProviderTransactionID string       `json:"provider_transaction_id"`
+	CreatedBy             string       `json:"created_by"`
+	CreatedAt             sql.NullTime `json:"created_at"`
+}
+
+type CustomerDeletion struct {
+	CustomerID string       `json:"customer_id"`
+	TenantID   string       `json:"tenant_id"`
+	DeletedAt  time.Time    `json:"deleted_at"`
+	PurgeAfter time.Time    `json:"purge_after"`
+	PurgedAt   sql.NullTime `json:"purged_at"`
+	CreatedAt  sql.NullTime `json:"created_at"`
+}
+
+type CustomerErasure struct {
+	CustomerID string       `json:"customer_id"`
+	TenantID   string       `json:"tenant_id"`
+	ErasedAt   time.Time    `json:"erased_at"`
+	CreatedAt  sql.NullTime `json:"created_at"`
+}
+
+type CustomerHold struct {
+	ID                  string                `json:"id"`
+	TenantID            string                `json:"tenant_id"`
+	CustomerID          string                `json:"customer_id"`
+	Reason              string                `json:"reason"`
+	SourceID            string                `json:"source_id"`
+	Status              string                `json:"status"`
+	BlocksCharges       bool                  `json:"blocks_charges"`
+	PausedSubscriptions pqtype.NullRawMessage `json:"paused_subscriptions"`
+	ReleaseReason       sql.NullString        `json:"release_reason"`
+	CreatedAt           sql.NullTime          `json:"created_at"`
+	UpdatedAt           sql.NullTime          `json:"updated_at"`
+	ReleasedAt          sql.NullTime          `json:"released_at"`
+}
+
+type CustomerIdentity struct {
+	CustomerID            string       `json:"customer_id"`
+	TenantID              string       `json:"tenant_id"`
+	NormalizedEmail       string       `json:"normalized_email"`
+	Name                  string       `json:"name"`
+	EmailVerifiedAt       sql.NullTime `json:"email_verified_at"`
+	VerificationTokenHash string       `json:"verification_token_hash"`
+	VerificationExpiresAt sql.NullTime `json:"verification_expires_at"`
+	CreatedAt             sql.NullTime `json:"created_at"`
+	UpdatedAt             sql.NullTime `json:"updated_at"`
+}
+
+type CustomerReference struct {
+	TenantID          string       `json:"tenant_id"`
+	ExternalReference string       `json:"external_reference"`
+	CustomerID        string       `json:"customer_id"`
+	CreatedAt         sql.NullTime `json:"created_at"`
+}
+
+type DeprecatedUsage struct {
+	NoticeID     string    `json:"notice_id"`
+	ApiKeyID     string    `json:"api_key_id"`
+	RequestCount int64     `json:"request_count"`
+	FirstSeen    time.Time `json:"first_seen"`
+	LastSeen     time.Time `json:"last_seen"`
+}
+
+type Dispute struct {
+	ID                    string          `json:"id"`
+	ChargeID              string          `json:"charge_id"`
+	PaymentIntentID       string          `json:"payment_intent_id"`
+	Amount                int64           `json:"amount"`
+	Currency              string          `json:"currency"`
+	Reason                string          `json:"reason"`
+	Status                string          `json:"status"`
+	NetworkReasonCode     string          `json:"network_reason_code"`
+	IsChargeRefundable    bool            `json:"is_charge_refundable"`
+	Evidence              json.RawMessage `json:"evidence"`
+	EvidenceDueBy         sql.NullTime    `json:"evidence_due_by"`
+	HasEvidence           bool            `json:"has_evidence"`
+	PastDue               bool            `json:"past_due"`
+	SubmissionCount       int32           `json:"submission_count"`
+	PaymentMethodType     string          `json:"payment_method_type"`
+	CardBrand             string          `json:"card_brand"`
+	CardNetworkReasonCode string          `json:"card_network_reason_code"`
+	BalanceTransactions   json.RawMessage `json:"balance_transactions"`
+	Livemode              bool            `json:"livemode"`
+	Metadata              json.RawMessage `json:"metadata"`
+	DisputedAt            time.Time       `json:"disputed_at"`
+	SyncedAt              time.Time       `json:"synced_at"`
+	CreatedAt             sql.NullTime    `json:"created_at"`
+	UpdatedAt             sql.NullTime    `json:"updated_at"`
+	TenantID              string          `json:"tenant_id"`
+}
+
+type DisputeEvidence struct {
+	DisputeID   string          `json:"dispute_id"`
+	Fields      json.RawMessage `json:"fields"`
+	UpdatedBy   string          `json:"updated_by"`
+	SubmittedBy string          `json:"submitted_by"`
+	SubmittedAt sql.NullTime    `json:"submitted_at"`
+	CreatedAt   sql.NullTime    `json:"created_at"`
+	UpdatedAt   sql.NullTime    `json:"updated_at"`
+}
+
+type DisputeEvidenceFile struct {
+	ID             string       `json:"id"`
+	DisputeID      string       `json:"dispute_id"`
+	ProviderFileID string       `json:"provider_file_id"`
+	Field          string       `json:"field"`
+	Filename       string       `json:"filename"`
+	ContentType    string       `json:"content_type"`
+	Size           int64        `json:"size"`
+	UploadedBy     string       `json:"uploaded_by"`
+	CreatedAt      sql.NullTime `json:"created_at"`
+}
+
+type DisputeEvidenceReminder struct {
+	DisputeID string       `json:"dispute_id"`
+	DueBy     time.Time    `json:"due_by"`
+	SentAt    sql.NullTime `json:"sent_at"`
+}
+
+type DlqEvent struct {
+	ID            string          `json:"id"`
+	Source        string          `json:"source"`
+	DedupeKey     string          `json:"dedupe_key"`
+	Payload       []byte          `json:"payload"`
+	Headers       json.RawMessage `json:"headers"`
+	Status        string          `json:"status"`
+	Attempts      int32           `json:"attempts"`
+	LastError     string          `json:"last_error"`
+	NextAttemptAt sql.NullTime    `json:"next_attempt_at"`
+	ResolvedAt    sql.NullTime    `json:"resolved_at"`
+	CreatedAt     sql.NullTime    `json:"created_at"`
+	UpdatedAt     sql.NullTime    `json:"updated_at"`
+}
+
+type Document struct {
+	ID        string       `json:"id"`
+	TenantID  string       `json:"tenant_id"`
+	Kind      string       `json:"kind"`
+	SourceID  string       `json:"source_id"`
+	Language  string       `json:"language"`
+	ObjectKey string       `json:"object_key"`
+	Checksum  string       `json:"checksum"`
+	SizeBytes int64        `json:"size_bytes"`
+	CreatedAt sql.NullTime `json:"created_at"`
+	UpdatedAt sql.NullTime `json:"updated_at"`
+}
+
+type DocumentTemplate struct {
+	TenantID     string       `json:"tenant_id"`
+	Kind         string       `json:"kind"`
+	BusinessName string       `json:"business_name"`
+	Address      string       `json:"address"`
+	TaxID        string       `json:"tax_id"`
+	AccentColor  string       `json:"accent_color"`
+	Footer       string       `json:"footer"`
+	Language     string       `json:"language"`
+	CreatedAt    sql.NullTime `json:"created_at"`
+	UpdatedAt    sql.NullTime `json:"updated_at"`
+}
+
+type DunningCase struct {
+	ID             string       `json:"id"`
+	InvoiceID      string       `json:"invoice_id"`
+	SubscriptionID string       `json:"subscription_id"`
+	CustomerID     string       `json:"customer_id"`
+	AmountDue      int64        `json:"amount_due"`
+	Currency       string       `json:"currency"`
+	State          string       `json:"state"`
+	Attempts       int32        `json:"attempts"`
+	NextRetryAt    sql.NullTime `json:"next_retry_at"`
+	LastError      string       `json:"last_error"`
+	FailedAt       time.Time    `json:"failed_at"`
+	ResolvedAt     sql.NullTime `json:"resolved_at"`
+	CreatedAt      sql.NullTime `json:"created_at"`
+	UpdatedAt      sql.NullTime `json:"updated_at"`
+	TenantID       string       `json:"tenant_id"`
+}
+
+type EntityVersion struct {
+	ID         int64           `json:"id"`
+	EntityType string          `json:"entity_type"`
+	EntityID   string          `json:"entity_id"`
+	Status     string          `json:"status"`
+	State      json.RawMessage `json:"state"`
+	EventID    string          `json:"event_id"`
+	EventType  string          `json:"event_type"`
+	ValidFrom  time.Time       `json:"valid_from"`
+	RecordedAt sql.NullTime    `json:"recorded_at"`
+}
+
+type EphemeralKey struct {
+	ID         string          `json:"id"`
+	CustomerID string          `json:"customer_id"`
+	SecretHash string          `json:"secret_hash"`
+	Scopes     json.RawMessage `json:"scopes"`
+	IssuedBy   string          `json:"issued_by"`
+	ExpiresAt  time.Time       `json:"expires_at"`
+	RevokedAt  sql.NullTime    `json:"revoked_at"`
+	CreatedAt  sql.NullTime    `json:"created_at"`
+}
+
+type EventArchive struct {
+	ID         string          `json:"id"`
+	TenantID   string          `json:"tenant_id"`
+	Type       string          `json:"type"`
+	Subject    string          `json:"subject"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Payload    json.RawMessage `json:"payload"`
+	ArchivedAt sql.NullTime    `json:"archived_at"`
+}
+
+type EventOutbox struct {
+	ID        string          `json:"id"`
+	TenantID  string          `json:"tenant_id"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt sql.NullTime    `json:"created_at"`
+}
+
+type FraudListEntry struct {
+	ID        string       `json:"id"`
+	List      string       `json:"list"`
+	EntryType string       `json:"entry_type"`
+	Value     string       `json:"value"`
+	Reason    string       `json:"reason"`
+	CreatedBy string       `json:"created_by"`
+	CreatedAt sql.NullTime `json:"created_at"`
+}
+
+type FraudRadarScore struct {
+	ChargeID        string       `json:"charge_id"`
+	CustomerID      string       `json:"customer_id"`
+	CardFingerprint string       `json:"card_fingerprint"`
+	RiskScore       int32        `json:"risk_score"`
+	RiskLevel       string       `json:"risk_level"`
+	CreatedAt       sql.NullTime `json:"created_at"`
+}
+
+type FraudReview struct {
+	ID            string          `json:"id"`
+	TenantID      string          `json:"tenant_id"`
+	ScreeningID   string          `json:"screening_id"`
+	CustomerID    string          `json:"customer_id"`
+	Amount        int64           `json:"amount"`
+	Currency      string          `json:"currency"`
+	Request       json.RawMessage `json:"request"`
+	Findings      json.RawMessage `json:"findings"`
+	Status        string          `json:"status"`
+	DecidedBy     string          `json:"decided_by"`
+	ChargeID      string          `json:"charge_id"`
+	FailureReason string          `json:"failure_reason"`
+	DecidedAt     sql.NullTime    `json:"decided_at"`
+	CreatedAt     sql.NullTime    `json:"created_at"`
+	UpdatedAt     sql.NullTime    `json:"updated_at"`
+}
+
+type FraudScreening struct {
+	ID              string          `json:"id"`
+	TenantID        string          `json:"tenant_id"`
+	CustomerID      string          `json:"customer_id"`
+	CardFingerprint string          `json:"card_fingerprint"`
+	IpAddress       string          `json:"ip_address"`
+	Amount          int64           `json:"amount"`
+	Currency        string          `json:"currency"`
+	Decision        string          `json:"decision"`
+	Findings        json.RawMessage `json:"findings"`
+	CreatedAt       sql.NullTime    `json:"created_at"`
+}
+
+type HeldMutation struct {
+	ID             string         `json:"id"`
+	QuarantineID   string         `json:"quarantine_id"`
+	TenantID       string         `json:"tenant_id"`
+	ApiKeyID       string         `json:"api_key_id"`
+	OperatorID     string         `json:"operator_id"`
+	Method         string         `json:"method"`
+	Path           string         `json:"path"`
+	ContentType    string         `json:"content_type"`
+	Body           string         `json:"body"`
+	Status         string         `json:"status"`
+	DecidedBy      sql.NullString `json:"decided_by"`
+	DecidedAt      sql.NullTime   `json:"decided_at"`
+	ResponseStatus int32          `json:"response_status"`
+	ResponseBody   string         `json:"response_body"`
+	CreatedAt      sql.NullTime   `json:"created_at"`
+	UpdatedAt      sql.NullTime   `json:"updated_at"`
+}
+
+type HoldPolicy struct {
+	TenantID              string       `json:"tenant_id"`
+	PauseOnDispute        bool         `json:"pause_on_dispute"`
+	PauseOnFraud          bool         `json:"pause_on_fraud"`
+	BlockChargesOnDispute bool         `json:"block_charges_on_dispute"`
+	BlockChargesOnFraud   bool         `json:"block_charges_on_fraud"`
+	MinRiskScore          int32        `json:"min_risk_score"`
+	AutoRelease           bool         `json:"auto_release"`
+	CreatedAt             sql.NullTime `json:"created_at"`
+	UpdatedAt             sql.NullTime `json:"updated_at"`
+}
+
+type Invoice struct {
+	ID               string          `json:"id"`
+	CustomerID       string          `json:"customer_id"`
+	SubscriptionID   string          `json:"subscription_id"`
+	Number           string          `json:"number"`
+	Status           string          `json:"status"`
+	CollectionMethod string          `json:"collection_method"`
+	Description      string          `json:"description"`
+	AmountDue        int64           `json:"amount_due"`
+	AmountPaid       int64           `json:"amount_paid"`
+	AmountRemaining  int64           `json:"amount_remaining"`
+	Currency         string          `json:"currency"`
+	DueDate          sql.NullTime    `json:"due_date"`
+	PaidOutOfBand    bool            `json:"paid_out_of_band"`
+	HostedInvoiceUrl string          `json:"hosted_invoice_url"`
+	InvoicePdf       string          `json:"invoice_pdf"`
+	CustomFields     json.RawMessage `json:"custom_fields"`
+	Metadata         json.RawMessage `json:"metadata"`
+	InvoicedAt       time.Time       `json:"invoiced_at"`
+	SyncedAt         time.Time       `json:"synced_at"`
+	CreatedAt        sql.NullTime    `json:"created_at"`
+	UpdatedAt        sql.NullTime    `json:"updated_at"`
+	CustomerName     string          `json:"customer_name"`
+	CustomerEmail    string          `json:"customer_email"`
+	Subtotal         int64           `json:"subtotal"`
+	Tax              int64           `json:"tax"`
+	Total            int64           `json:"total"`
+	Lines            json.RawMessage `json:"lines"`
+	TaxAmounts       json.RawMessage `json:"tax_amounts"`
+	TenantID         string          `json:"tenant_id"`
+}
+
+type InvoiceReminder struct {
+	InvoiceID  string       `json:"invoice_id"`
+	OffsetDays int32        `json:"offset_days"`
+	SentAt     sql.NullTime `json:"sent_at"`
+}
+
+type JobRun struct {
+	ID          string       `json:"id"`
+	JobName     string       `json:"job_name"`
+	Status      string       `json:"status"`
+	TriggeredBy string       `json:"triggered_by"`
+	Attempts    int32        `json:"attempts"`
+	Error       string       `json:"error"`
+	Instance    string       `json:"instance"`
+	StartedAt   time.Time    `json:"started_at"`
+	FinishedAt  sql.NullTime `json:"finished_at"`
+	CreatedAt   sql.NullTime `json:"created_at"`
+}
+
+type LedgerEntry struct {
+	ID            string       `json:"id"`
+	DebitAccount  string       `json:"debit_account"`
+	CreditAccount string       `json:"credit_account"`
+	Amount        int64        `json:"amount"`
+	Currency      string       `json:"currency"`
+	ReferenceType string       `json:"reference_type"`
+	ReferenceID   string       `json:"reference_id"`
+	Description   string       `json:"description"`
+	CreatedAt     sql.NullTime `json:"created_at"`
+}
+
+type MetadataSchema struct {
+	TenantID  string          `json:"tenant_id"`
+	Resource  string          `json:"resource"`
+	Schema    json.RawMessage `json:"schema"`
+	CreatedAt sql.NullTime    `json:"created_at"`
+	UpdatedAt sql.NullTime    `json:"updated_at"`
+}
+
+type OffboardingArchive struct {
+	ExportID  string       `json:"export_id"`
+	Archive   []byte       `json:"archive"`
+	CreatedAt sql.NullTime `json:"created_at"`
+}
+
+type OffboardingExport struct {
+	ID                      string                `json:"id"`
+	TenantID                string                `json:"tenant_id"`
+	Status                  string                `json:"status"`
+	RecipientPublicKey      string                `json:"recipient_public_key"`
+	Manifest                pqtype.NullRawMessage `json:"manifest"`
+	ArchiveSha256           string                `json:"archive_sha256"`
+	ArchiveSize             int64                 `json:"archive_size"`
+	Error                   string                `json:"error"`
+	PanMigrationStatus      string                `json:"pan_migration_status"`
+	PanMigrationDestination string                `json:"pan_migration_destination"`
+	PanMigrationReference   string                `json:"pan_migration_reference"`
+	PanMigrationRequestedAt sql.NullTime          `json:"pan_migration_requested_at"`
+	StartedAt               sql.NullTime          `json:"started_at"`
+	CompletedAt             sql.NullTime          `json:"completed_at"`
+	CreatedAt               sql.NullTime          `json:"created_at"`
+	UpdatedAt               sql.NullTime          `json:"updated_at"`
+}
+
+type PaymentLink struct {
+	ID              string          `json:"id"`
+	Url             string          `json:"url"`
+	Amount          int64           `json:"amount"`
+	Currency        string          `json:"currency"`
+	ProductName     string          `json:"product_name"`
+	PriceID         string          `json:"price_id"`
+	Active          bool            `json:"active"`
+	ExpiresAt       sql.NullTime    `json:"expires_at"`
+	MaxUses         int64           `json:"max_uses"`
+	Conversions     int64           `json:"conversions"`
+	AmountCollected int64           `json:"amount_collected"`
+	Metadata        json.RawMessage `json:"metadata"`
+	DeactivatedAt   sql.NullTime    `json:"deactivated_at"`
+	CreatedAt       sql.NullTime    `json:"created_at"`
+	UpdatedAt       sql.NullTime    `json:"updated_at"`
+	TenantID        string          `json:"tenant_id"`
+}
+
+type PaymentLinkConversion struct {
+	SessionID       string       `json:"session_id"`
+	PaymentLinkID   string       `json:"payment_link_id"`
+	AmountTotal     int64        `json:"amount_total"`
+	Currency        string       `json:"currency"`
+	CustomerID      string       `json:"customer_id"`
+	PaymentIntentID string       `json:"payment_intent_id"`
+	CompletedAt     time.Time    `json:"completed_at"`
+	CreatedAt       sql.NullTime `json:"created_at"`
 }
 
 type PaymentMethod struct {
@@ -45,6 +679,118 @@ type PaymentMethod struct {
 	CardFingerprint sql.NullString        `json:"card_fingerprint"`
 	Metadata        pqtype.NullRawMessage `json:"metadata"`
 	CreatedAt       sql.NullTime          `json:"created_at"`
+	SyncedAt        time.Time             `json:"synced_at"`
+	TenantID        string                `json:"tenant_id"`
+}
+
+type PaymentMethodPreference struct {
+	TenantID             string          `json:"tenant_id"`
+	CustomerID           string          `json:"customer_id"`
+	DefaultPaymentMethod string          `json:"default_payment_method"`
+	BackupPaymentMethods json.RawMessage `json:"backup_payment_methods"`
+	CreatedAt            sql.NullTime    `json:"created_at"`
+	UpdatedAt            sql.NullTime    `json:"updated_at"`
+}
+
+type ProviderCredential struct {
+	TenantID     string          `json:"tenant_id"`
+	Provider     string          `json:"provider"`
+	Ciphertext   []byte          `json:"ciphertext"`
+	MaskedFields json.RawMessage `json:"masked_fields"`
+	Version      int32           `json:"version"`
+	VerifiedAt   time.Time       `json:"verified_at"`
+	CreatedAt    sql.NullTime    `json:"created_at"`
+	UpdatedAt    sql.NullTime    `json:"updated_at"`
+}
+
+type ProviderCredentialAudit struct {
+	ID            string          `json:"id"`
+	TenantID      string          `json:"tenant_id"`
+	Provider      string          `json:"provider"`
+	Action        string          `json:"action"`
+	Version       int32           `json:"version"`
+	ChangedFields json.RawMessage `json:"changed_fields"`
+	ApiKeyID      string          `json:"api_key_id"`
+	OperatorID    string          `json:"operator_id"`
+	CreatedAt     sql.NullTime    `json:"created_at"`
+}
+
+type Quarantine struct {
+	ID          string         `json:"id"`
+	SubjectType string         `json:"subject_type"`
+	SubjectID   string         `json:"subject_id"`
+	Reason      string         `json:"reason"`
+	CreatedBy   string         `json:"created_by"`
+	LiftedBy    sql.NullString `json:"lifted_by"`
+	LiftedAt    sql.NullTime   `json:"lifted_at"`
+	CreatedAt   sql.NullTime   `json:"created_at"`
+	UpdatedAt   sql.NullTime   `json:"updated_at"`
+}
+
+type QuarantineActivity struct {
+	ID             string       `json:"id"`
+	QuarantineID   string       `json:"quarantine_id"`
+	TenantID       string       `json:"tenant_id"`
+	ApiKeyID       string       `json:"api_key_id"`
+	Method         string       `json:"method"`
+	Path           string       `json:"path"`
+	HeldMutationID string       `json:"held_mutation_id"`
+	CreatedAt      sql.NullTime `json:"created_at"`
+}
+
+type ReceiptSetting struct {
+	TenantID          string       `json:"tenant_id"`
+	SendReceiptEmails bool         `json:"send_receipt_emails"`
+	DescriptorSuffix  string       `json:"descriptor_suffix"`
+	BusinessName      string       `json:"business_name"`
+	SupportEmail      string       `json:"support_email"`
+	CreatedAt         sql.NullTime `json:"created_at"`
+	UpdatedAt         sql.NullTime `json:"updated_at"`
+}
+
+type ReceivableInvoice struct {
+	InvoiceID       string       `json:"invoice_id"`
+	CustomerID      string       `json:"customer_id"`
+	SubscriptionID  string       `json:"subscription_id"`
+	Number          string       `json:"number"`
+	AmountDue       int64        `json:"amount_due"`
+	AmountRemaining int64        `json:"amount_remaining"`
+	Currency        string       `json:"currency"`
+	DueDate         time.Time    `json:"due_date"`
+	Status          string       `json:"status"`
+	OverdueAt       sql.NullTime `json:"overdue_at"`
+	PaidAt          sql.NullTime `json:"paid_at"`
+	PaidReference   string       `json:"paid_reference"`
+	CreatedAt       sql.NullTime `json:"created_at"`
+	UpdatedAt       sql.NullTime `json:"updated_at"`
+}
+
+type ReconciliationMismatch struct {
+	ID                   string       `json:"id"`
+	RunID                string       `json:"run_id"`
+	Kind                 string       `json:"kind"`
+	ObjectType           string       `json:"object_type"`
+	ObjectID             string       `json:"object_id"`
+	BalanceTransactionID string       `json:"balance_transaction_id"`
+	ProviderAmount       int64        `json:"provider_amount"`
+	LocalAmount          int64        `json:"local_amount"`
+	Currency             string       `json:"currency"`
+	Detail               string       `json:"detail"`
+	CreatedAt            sql.NullTime `json:"created_at"`
+}
+
+type ReconciliationRun struct {
+	ID                  string       `json:"id"`
+	Status              string       `json:"status"`
+	PeriodStart         time.Time    `json:"period_start"`
+	PeriodEnd           time.Time    `json:"period_end"`
+	TransactionsChecked int64        `json:"transactions_checked"`
+	PayoutsChecked      int64        `json:"payouts_checked"`
+	Matched             int64        `json:"matched"`
+	Mismatches          int64        `json:"mismatches"`
+	Error               string       `json:"error"`
+	StartedAt           time.Time    `json:"started_at"`
+	CompletedAt         sql.NullTime `json:"completed_at"`
 }
 
 type Refund struct {
@@ -57,4 +803,266 @@ type Refund struct {
 	Metadata  pqtype.NullRawMessage `json:"metadata"`
 	CreatedAt sql.NullTime          `json:"created_at"`
 	UpdatedAt sql.NullTime          `json:"updated_at"`
+	SyncedAt  time.Time             `json:"synced_at"`
+	TenantID  string                `json:"tenant_id"`
+}
+
+type RefundActivity struct {
+	ID         string       `json:"id"`
+	TenantID   string       `json:"tenant_id"`
+	ApiKeyID   string       `json:"api_key_id"`
+	OperatorID string       `json:"operator_id"`
+	RefundID   string       `json:"refund_id"`
+	ChargeID   string       `json:"charge_id"`
+	Amount     int64        `json:"amount"`
+	Currency   string       `json:"currency"`
+	CreatedAt  sql.NullTime `json:"created_at"`
+}
+
+type RefundApproval struct {
+	ID         string          `json:"id"`
+	TenantID   string          `json:"tenant_id"`
+	ApiKeyID   string          `json:"api_key_id"`
+	OperatorID string          `json:"operator_id"`
+	Request    json.RawMessage `json:"request"`
+	Amount     int64           `json:"amount"`
+	Reasons    json.RawMessage `json:"reasons"`
+	Status     string          `json:"status"`
+	DecidedBy  sql.NullString  `json:"decided_by"`
+	RefundID   sql.NullString  `json:"refund_id"`
+	CreatedAt  sql.NullTime    `json:"created_at"`
+	UpdatedAt  sql.NullTime    `json:"updated_at"`
+	DecidedAt  sql.NullTime    `json:"decided_at"`
+}
+
+type RefundBatch struct {
+	ID          string          `json:"id"`
+	TenantID    string          `json:"tenant_id"`
+	ApiKeyID    string          `json:"api_key_id"`
+	OperatorID  string          `json:"operator_id"`
+	Requests    json.RawMessage `json:"requests"`
+	Status      string          `json:"status"`
+	ScheduledAt time.Time       `json:"scheduled_at"`
+	StartedAt   sql.NullTime    `json:"started_at"`
+	CompletedAt sql.NullTime    `json:"completed_at"`
+	CreatedAt   sql.NullTime    `json:"created_at"`
+	UpdatedAt   sql.NullTime    `json:"updated_at"`
+}
+
+type RefundBatchItem struct {
+	BatchID    string       `json:"batch_id"`
+	ItemIndex  int32        `json:"item_index"`
+	Status     string       `json:"status"`
+	Amount     int64        `json:"amount"`
+	RefundID   string       `json:"refund_id"`
+	ApprovalID string       `json:"approval_id"`
+	Error      string       `json:"error"`
+	CreatedAt  sql.NullTime `json:"created_at"`
+}
+
+type RoutedCharge struct {
+	ChargeID           string       `json:"charge_id"`
+	Provider           string       `json:"provider"`
+	Currency           string       `json:"currency"`
+	Amount             int64        `json:"amount"`
+	SettlementCurrency string       `json:"settlement_currency"`
+	Reason             string       `json:"reason"`
+	CreatedAt          sql.NullTime `json:"created_at"`
+}
+
+type SimulatorObject struct {
+	TenantID  string          `json:"tenant_id"`
+	ID        string          `json:"id"`
+	Kind      string          `json:"kind"`
+	ParentID  string          `json:"parent_id"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+type SmartRoutingRule struct {
+	ID             string          `json:"id"`
+	Currency       string          `json:"currency"`
+	BinCountry     string          `json:"bin_country"`
+	MinAmount      int64           `json:"min_amount"`
+	MaxAmount      int64           `json:"max_amount"`
+	Strategy       string          `json:"strategy"`
+	Providers      json.RawMessage `json:"providers"`
+	PinnedProvider string          `json:"pinned_provider"`
+	Priority       int32           `json:"priority"`
+	Enabled        bool            `json:"enabled"`
+	Description    string          `json:"description"`
+	UpdatedBy      string          `json:"updated_by"`
+	CreatedAt      sql.NullTime    `json:"created_at"`
+	UpdatedAt      sql.NullTime    `json:"updated_at"`
+}
+
+type Subscription struct {
+	ID                    string          `json:"id"`
+	CustomerID            string          `json:"customer_id"`
+	PlanID                string          `json:"plan_id"`
+	Status                string          `json:"status"`
+	Paused                bool            `json:"paused"`
+	CancelAtPeriodEnd     bool            `json:"cancel_at_period_end"`
+	CurrentPeriodStart    time.Time       `json:"current_period_start"`
+	CurrentPeriodEnd      time.Time       `json:"current_period_end"`
+	CollectionMethod      string          `json:"collection_method"`
+	DaysUntilDue          int64           `json:"days_until_due"`
+	Metadata              json.RawMessage `json:"metadata"`
+	SubscriptionCreatedAt time.Time       `json:"subscription_created_at"`
+	SyncedAt              time.Time       `json:"synced_at"`
+	CreatedAt             sql.NullTime    `json:"created_at"`
+	UpdatedAt             sql.NullTime    `json:"updated_at"`
+	TenantID              string          `json:"tenant_id"`
+}
+
+type SubscriptionPlan struct {
+	ID              string          `json:"id"`
+	ProductID       string          `json:"product_id"`
+	Nickname        string          `json:"nickname"`
+	Amount          int64           `json:"amount"`
+	Currency        string          `json:"currency"`
+	BillingInterval string          `json:"billing_interval"`
+	IntervalCount   int64           `json:"interval_count"`
+	Active          bool            `json:"active"`
+	Metadata        json.RawMessage `json:"metadata"`
+	PlanCreatedAt   time.Time       `json:"plan_created_at"`
+	SyncedAt        time.Time       `json:"synced_at"`
+	CreatedAt       sql.NullTime    `json:"created_at"`
+	UpdatedAt       sql.NullTime    `json:"updated_at"`
+}
+
+type TenantBudget struct {
+	TenantID     string          `json:"tenant_id"`
+	MonthlyLimit int64           `json:"monthly_limit"`
+	Thresholds   json.RawMessage `json:"thresholds"`
+	HardStop     bool            `json:"hard_stop"`
+	UpdatedBy    string          `json:"updated_by"`
+	CreatedAt    sql.NullTime    `json:"created_at"`
+	UpdatedAt    sql.NullTime    `json:"updated_at"`
+}
+
+type TenantBudgetAlert struct {
+	TenantID  string       `json:"tenant_id"`
+	Period    string       `json:"period"`
+	Threshold int32        `json:"threshold"`
+	AlertedAt sql.NullTime `json:"alerted_at"`
+}
+
+type TenantConfig struct {
+	TenantID        string       `json:"tenant_id"`
+	Name            string       `json:"name"`
+	Status          string       `json:"status"`
+	DefaultProvider string       `json:"default_provider"`
+	CreatedAt       sql.NullTime `json:"created_at"`
+	UpdatedAt       sql.NullTime `json:"updated_at"`
+}
+
+type TenantSpend struct {
+	TenantID    string `json:"tenant_id"`
+	Period      string `json:"period"`
+	Amount      int64  `json:"amount"`
+	ChargeCount int64  `json:"charge_count"`
+}
+
+type UnclaimedBalance struct {
+	CustomerID string       `json:"customer_id"`
+	Currency   string       `json:"currency"`
+	Amount     int64        `json:"amount"`
+	FundedAt   sql.NullTime `json:"funded_at"`
+	CreatedAt  sql.NullTime `json:"created_at"`
+	UpdatedAt  sql.NullTime `json:"updated_at"`
+}
+
+type UsageRecord struct {
+	ID                 string       `json:"id"`
+	SubscriptionID     string       `json:"subscription_id"`
+	SubscriptionItemID string       `json:"subscription_item_id"`
+	PriceID            string       `json:"price_id"`
+	Quantity           int64        `json:"quantity"`
+	Action             string       `json:"action"`
+	RecordedAt         time.Time    `json:"recorded_at"`
+	IdempotencyKey     string       `json:"idempotency_key"`
+	ProviderRecordID   string       `json:"provider_record_id"`
+	ReportedAt         sql.NullTime `json:"reported_at"`
+	Attempts           int32        `json:"attempts"`
+	LastError          string       `json:"last_error"`
+	CreatedAt          sql.NullTime `json:"created_at"`
+	UpdatedAt          sql.NullTime `json:"updated_at"`
+}
+
+type VaultToken struct {
+	ID            string       `json:"id"`
+	Provider      string       `json:"provider"`
+	ProviderToken string       `json:"provider_token"`
+	CustomerID    string       `json:"customer_id"`
+	Type          string       `json:"type"`
+	Fingerprint   string       `json:"fingerprint"`
+	CreatedAt     sql.NullTime `json:"created_at"`
+	UpdatedAt     sql.NullTime `json:"updated_at"`
+	TenantID      string       `json:"tenant_id"`
+}
+
+type WebhookDelivery struct {
+	ID             string       `json:"id"`
+	TenantID       string       `json:"tenant_id"`
+	EndpointID     string       `json:"endpoint_id"`
+	EventID        string       `json:"event_id"`
+	EventType      string       `json:"event_type"`
+	Payload        []byte       `json:"payload"`
+	Status         string       `json:"status"`
+	Attempts       int32        `json:"attempts"`
+	LastStatusCode int32        `json:"last_status_code"`
+	LastError      string       `json:"last_error"`
+	NextAttemptAt  sql.NullTime `json:"next_attempt_at"`
+	DeliveredAt    sql.NullTime `json:"delivered_at"`
+	CreatedAt      sql.NullTime `json:"created_at"`
+	UpdatedAt      sql.NullTime `json:"updated_at"`
+}
+
+type WebhookDeliveryAttempt struct {
+	ID           string       `json:"id"`
+	DeliveryID   string       `json:"delivery_id"`
+	StatusCode   int32        `json:"status_code"`
+	ResponseBody string       `json:"response_body"`
+	Error        string       `json:"error"`
+	DurationMs   int64        `json:"duration_ms"`
+	Manual       bool         `json:"manual"`
+	AttemptedAt  sql.NullTime `json:"attempted_at"`
+}
+
+type WebhookEndpoint struct {
+	ID               string          `json:"id"`
+	TenantID         string          `json:"tenant_id"`
+	Url              string          `json:"url"`
+	Description      string          `json:"description"`
+	EventTypes       json.RawMessage `json:"event_types"`
+	SecretCiphertext []byte          `json:"secret_ciphertext"`
+	Enabled          bool            `json:"enabled"`
+	CreatedAt        sql.NullTime    `json:"created_at"`
+	UpdatedAt        sql.NullTime    `json:"updated_at"`
+}
+
+type WebhookEvent struct {
+	ID          string                `json:"id"`
+	Type        string                `json:"type"`
+	Created     int64                 `json:"created"`
+	Source      string                `json:"source"`
+	ProcessedAt sql.NullTime          `json:"processed_at"`
+	Payload     pqtype.NullRawMessage `json:"payload"`
+}
+
+type WebhookSecretRotation struct {
+	ID            string       `json:"id"`
+	Status        string       `json:"status"`
+	OldEndpointID string       `json:"old_endpoint_id"`
+	NewEndpointID string       `json:"new_endpoint_id"`
+	NewSecret     []byte       `json:"new_secret"`
+	Deliveries    int32        `json:"deliveries"`
+	ConfirmedAt   sql.NullTime `json:"confirmed_at"`
+	GraceEndsAt   time.Time    `json:"grace_ends_at"`
+	CompletedAt   sql.NullTime `json:"completed_at"`
+	CancelledAt   sql.NullTime `json:"cancelled_at"`
+	CreatedAt     sql.NullTime `json:"created_at"`
+	UpdatedAt     sql.NullTime `json:"updated_at"`
 }
@@ -6,32 +6,351 @@ package sqlc
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
 type Querier interface {
+	AbandonJobRuns(ctx context.Context, db DBTX, arg AbandonJobRunsParams) error
+	AddPaymentLinkConversion(ctx context.Context, db DBTX, arg AddPaymentLinkConversionParams) (PaymentLink, error)
+	AddTenantSpend(ctx context.Context, db DBTX, arg AddTenantSpendParams) (TenantSpend, error)
+	AdjustCustomerBalance(ctx context.Context, db DBTX, arg AdjustCustomerBalanceParams) (CustomerBalance, error)
+	AnonymizeChargeListRows(ctx context.Context, db DBTX, customerID string) error
+	AnonymizeCustomer(ctx context.Context, db DBTX, arg AnonymizeCustomerParams) error
+	AppendChargeTransition(ctx context.Context, db DBTX, arg AppendChargeTransitionParams) (ChargeTransition, error)
+	ArchiveEvent(ctx context.Context, db DBTX, arg ArchiveEventParams) error
+	ClaimCustomerDeletion(ctx context.Context, db DBTX, arg ClaimCustomerDeletionParams) (CustomerDeletion, error)
+	ClaimDisputeEvidenceReminder(ctx context.Context, db DBTX, arg ClaimDisputeEvidenceReminderParams) (int64, error)
+	ClaimDueDeadLetters(ctx context.Context, db DBTX, arg ClaimDueDeadLettersParams) ([]DlqEvent, error)
+	ClaimDueWebhookDeliveries(ctx context.Context, db DBTX, arg ClaimDueWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	ClaimOffboardingExport(ctx context.Context, db DBTX, id string) (OffboardingExport, error)
+	ClaimRefundApproval(ctx context.Context, db DBTX, arg ClaimRefundApprovalParams) (int64, error)
+	ClaimRefundBatch(ctx context.Context, db DBTX, id string) (RefundBatch, error)
+	CompleteOffboardingExport(ctx context.Context, db DBTX, arg CompleteOffboardingExportParams) (OffboardingExport, error)
+	CompleteReconciliationRun(ctx context.Context, db DBTX, arg CompleteReconciliationRunParams) (ReconciliationRun, error)
+	CountFingerprintChargebacks(ctx context.Context, db DBTX, arg CountFingerprintChargebacksParams) (int64, error)
+	CountFingerprintCustomers(ctx context.Context, db DBTX, arg CountFingerprintCustomersParams) (int64, error)
+	CountRecentFraudScreenings(ctx context.Context, db DBTX, arg CountRecentFraudScreeningsParams) (CountRecentFraudScreeningsRow, error)
+	CreateAPIKey(ctx context.Context, db DBTX, arg CreateAPIKeyParams) (ApiKey, error)
+	CreateAuditEntry(ctx context.Context, db DBTX, arg CreateAuditEntryParams) (int64, error)
+	CreateAutoRefund(ctx context.Context, db DBTX, arg CreateAutoRefundParams) (AutoRefund, error)
+	CreateBlocklistEntry(ctx context.Context, db DBTX, arg CreateBlocklistEntryParams) (BlocklistEntry, error)
 	CreateCharge(ctx context.Context, db DBTX, arg CreateChargeParams) (Charge, error)
+	CreateCompositeCharge(ctx context.Context, db DBTX, arg CreateCompositeChargeParams) (CompositeCharge, error)
+	CreateCompositeChargeLeg(ctx context.Context, db DBTX, arg CreateCompositeChargeLegParams) (CompositeChargeLeg, error)
+	CreateCompositeRefund(ctx context.Context, db DBTX, arg CreateCompositeRefundParams) (CompositeRefund, error)
+	CreateCompositeRefundLeg(ctx context.Context, db DBTX, arg CreateCompositeRefundLegParams) (CompositeRefundLeg, error)
 	CreateCustomer(ctx context.Context, db DBTX, arg CreateCustomerParams) (Customer, error)
+	CreateCustomerBalanceTransaction(ctx context.Context, db DBTX, arg CreateCustomerBalanceTransactionParams) (CustomerBalanceTransaction, error)
+	CreateCustomerDeletion(ctx context.Context, db DBTX, arg CreateCustomerDeletionParams) (CustomerDeletion, error)
+	CreateCustomerHold(ctx context.Context, db DBTX, arg CreateCustomerHoldParams) (CustomerHold, error)
+	CreateCustomerIdentity(ctx context.Context, db DBTX, arg CreateCustomerIdentityParams) (CustomerIdentity, error)
+	CreateCustomerReference(ctx context.Context, db DBTX, arg CreateCustomerReferenceParams) (CustomerReference, error)
+	CreateDeadLetter(ctx context.Context, db DBTX, arg CreateDeadLetterParams) (DlqEvent, error)
+	CreateDisputeEvidenceFile(ctx context.Context, db DBTX, arg CreateDisputeEvidenceFileParams) (DisputeEvidenceFile, error)
+	CreateEphemeralKey(ctx context.Context, db DBTX, arg CreateEphemeralKeyParams) (EphemeralKey, error)
+	CreateFraudListEntry(ctx context.Context, db DBTX, arg CreateFraudListEntryParams) (FraudListEntry, error)
+	CreateFraudReview(ctx context.Context, db DBTX, arg CreateFraudReviewParams) (FraudReview, error)
+	CreateHeldMutation(ctx context.Context, db DBTX, arg CreateHeldMutationParams) (HeldMutation, error)
+	CreateJobRun(ctx context.Context, db DBTX, arg CreateJobRunParams) (JobRun, error)
+	CreateLedgerEntry(ctx context.Context, db DBTX, arg CreateLedgerEntryParams) error
+	CreateOffboardingExport(ctx context.Context, db DBTX, arg CreateOffboardingExportParams) (OffboardingExport, error)
+	CreatePaymentLink(ctx context.Context, db DBTX, arg CreatePaymentLinkParams) (PaymentLink, error)
 	CreatePaymentMethod(ctx context.Context, db DBTX, arg CreatePaymentMethodParams) (PaymentMethod, error)
+	CreateProviderCredentialAudit(ctx context.Context, db DBTX, arg CreateProviderCredentialAuditParams) error
+	CreateQuarantine(ctx context.Context, db DBTX, arg CreateQuarantineParams) (Quarantine, error)
+	CreateReconciliationMismatch(ctx context.Context, db DBTX, arg CreateReconciliationMismatchParams) error
+	CreateReconciliationRun(ctx context.Context, db DBTX, arg CreateReconciliationRunParams) (ReconciliationRun, error)
 	CreateRefund(ctx context.Context, db DBTX, arg CreateRefundParams) (Refund, error)
+	CreateRefundApproval(ctx context.Context, db DBTX, arg CreateRefundApprovalParams) (RefundApproval, error)
+	CreateRefundBatch(ctx context.Context, db DBTX, arg CreateRefundBatchParams) (RefundBatch, error)
+	CreateSmartRoutingRule(ctx context.Context, db DBTX, arg CreateSmartRoutingRuleParams) (SmartRoutingRule, error)
+	CreateVaultToken(ctx context.Context, db DBTX, arg CreateVaultTokenParams) (VaultToken, error)
+	CreateWebhookDelivery(ctx context.Context, db DBTX, arg CreateWebhookDeliveryParams) (WebhookDelivery, error)
+	CreateWebhookDeliveryAttempt(ctx context.Context, db DBTX, arg CreateWebhookDeliveryAttemptParams) (WebhookDeliveryAttempt, error)
+	CreateWebhookEndpoint(ctx context.Context, db DBTX, arg CreateWebhookEndpointParams) (WebhookEndpoint, error)
+	CreateWebhookSecretRotation(ctx context.Context, db DBTX, arg CreateWebhookSecretRotationParams) (WebhookSecretRotation, error)
+	DeactivatePaymentLink(ctx context.Context, db DBTX, arg DeactivatePaymentLinkParams) (PaymentLink, error)
+	DecideFraudReview(ctx context.Context, db DBTX, arg DecideFraudReviewParams) (FraudReview, error)
+	DecideHeldMutation(ctx context.Context, db DBTX, arg DecideHeldMutationParams) (HeldMutation, error)
+	DecideRefundApproval(ctx context.Context, db DBTX, arg DecideRefundApprovalParams) (RefundApproval, error)
+	DeleteAutoRefundExclusion(ctx context.Context, db DBTX, customerID string) (int64, error)
+	DeleteBlocklistEntry(ctx context.Context, db DBTX, id string) (int64, error)
+	DeleteCustomFieldDefinition(ctx context.Context, db DBTX, tenantID string) (int64, error)
 	DeleteCustomer(ctx context.Context, db DBTX, id string) error
+	DeleteCustomerDeletion(ctx context.Context, db DBTX, customerID string) (int64, error)
+	DeleteCustomerIdentity(ctx context.Context, db DBTX, customerID string) error
+	DeleteCustomerPaymentMethods(ctx context.Context, db DBTX, customerID string) error
+	DeleteCustomerReferences(ctx context.Context, db DBTX, customerID string) error
+	DeleteDeadLetter(ctx context.Context, db DBTX, id string) (int64, error)
+	DeleteDocumentTemplate(ctx context.Context, db DBTX, arg DeleteDocumentTemplateParams) (int64, error)
+	DeleteFraudListEntry(ctx context.Context, db DBTX, id string) (int64, error)
+	DeleteInvoice(ctx context.Context, db DBTX, id string) error
+	DeleteMetadataSchema(ctx context.Context, db DBTX, arg DeleteMetadataSchemaParams) (int64, error)
+	DeleteMirroredPaymentMethod(ctx context.Context, db DBTX, arg DeleteMirroredPaymentMethodParams) error
+	DeleteOutboxEvent(ctx context.Context, db DBTX, id string) error
 	DeletePaymentMethod(ctx context.Context, db DBTX, arg DeletePaymentMethodParams) error
-	GetCharge(ctx context.Context, db DBTX, id string) (Charge, error)
+	DeleteProviderCredential(ctx context.Context, db DBTX, arg DeleteProviderCredentialParams) (int64, error)
+	DeleteSimulatorObject(ctx context.Context, db DBTX, arg DeleteSimulatorObjectParams) (int64, error)
+	DeleteSmartRoutingRule(ctx context.Context, db DBTX, id string) (int64, error)
+	DeleteTenantBudget(ctx context.Context, db DBTX, tenantID string) (int64, error)
+	DeleteVaultToken(ctx context.Context, db DBTX, id string) error
+	DeleteWebhookEndpoint(ctx context.Context, db DBTX, arg DeleteWebhookEndpointParams) (int64, error)
+	ExpireAPIKey(ctx context.Context, db DBTX, arg ExpireAPIKeyParams) (int64, error)
+	FailOffboardingExport(ctx context.Context, db DBTX, arg FailOffboardingExportParams) (OffboardingExport, error)
+	FinishJobRun(ctx context.Context, db DBTX, arg FinishJobRunParams) (JobRun, error)
+	FinishRefundBatch(ctx context.Context, db DBTX, arg FinishRefundBatchParams) (RefundBatch, error)
+	FinishWebhookSecretRotation(ctx context.Context, db DBTX, arg FinishWebhookSecretRotationParams) (WebhookSecretRotation, error)
+	GetAPIKey(ctx context.Context, db DBTX, id string) (ApiKey, error)
+	GetAPIKeyBySecretHash(ctx context.Context, db DBTX, secretHash string) (ApiKey, error)
+	GetActiveCustomerHoldBySource(ctx context.Context, db DBTX, sourceID string) (CustomerHold, error)
+	GetActiveQuarantine(ctx context.Context, db DBTX, arg GetActiveQuarantineParams) (Quarantine, error)
+	GetAuditEntry(ctx context.Context, db DBTX, sequence int64) (AuditLog, error)
+	GetAuthorization(ctx context.Context, db DBTX, arg GetAuthorizationParams) (Authorization, error)
+	GetAutoRefundExclusion(ctx context.Context, db DBTX, customerID string) (AutoRefundExclusion, error)
+	GetBlocklistEntry(ctx context.Context, db DBTX, id string) (BlocklistEntry, error)
+	GetBlocklistEntryByValue(ctx context.Context, db DBTX, arg GetBlocklistEntryByValueParams) (BlocklistEntry, error)
+	GetCharge(ctx context.Context, db DBTX, arg GetChargeParams) (Charge, error)
+	GetChargeCredentialStats(ctx context.Context, db DBTX, arg GetChargeCredentialStatsParams) ([]GetChargeCredentialStatsRow, error)
+	GetChargeCustomer(ctx context.Context, db DBTX, id string) (string, error)
 	GetChargeStats(ctx context.Context, db DBTX) (GetChargeStatsRow, error)
-	GetCustomer(ctx context.Context, db DBTX, id string) (Customer, error)
+	GetChargeTenant(ctx context.Context, db DBTX, id string) (string, error)
+	GetCompositeCharge(ctx context.Context, db DBTX, arg GetCompositeChargeParams) (CompositeCharge, error)
+	GetCompositeRefund(ctx context.Context, db DBTX, arg GetCompositeRefundParams) (CompositeRefund, error)
+	GetCustomFieldDefinition(ctx context.Context, db DBTX, tenantID string) (CustomFieldDefinition, error)
+	GetCustomer(ctx context.Context, db DBTX, arg GetCustomerParams) (Customer, error)
+	GetCustomerBalance(ctx context.Context, db DBTX, arg GetCustomerBalanceParams) (CustomerBalance, error)
+	GetCustomerBalanceTransactionBySource(ctx context.Context, db DBTX, arg GetCustomerBalanceTransactionBySourceParams) (CustomerBalanceTransaction, error)
 	GetCustomerByEmail(ctx context.Context, db DBTX, email string) (Customer, error)
+	GetCustomerChargeStats(ctx context.Context, db DBTX, arg GetCustomerChargeStatsParams) ([]GetCustomerChargeStatsRow, error)
+	GetCustomerDeletion(ctx context.Context, db DBTX, customerID string) (CustomerDeletion, error)
+	GetCustomerErasure(ctx context.Context, db DBTX, customerID string) (CustomerErasure, error)
+	GetCustomerHold(ctx context.Context, db DBTX, arg GetCustomerHoldParams) (CustomerHold, error)
+	GetCustomerIdentity(ctx context.Context, db DBTX, customerID string) (CustomerIdentity, error)
+	GetCustomerReference(ctx context.Context, db DBTX, arg GetCustomerReferenceParams) (CustomerReference, error)
+	GetCustomerScreenedAmounts(ctx context.Context, db DBTX, arg GetCustomerScreenedAmountsParams) (GetCustomerScreenedAmountsRow, error)
 	GetCustomerStats(ctx context.Context, db DBTX) (GetCustomerStatsRow, error)
-	GetPaymentMethod(ctx context.Context, db DBTX, id string) (PaymentMethod, error)
-	GetRefund(ctx context.Context, db DBTX, id string) (Refund, error)
+	GetDeadLetter(ctx context.Context, db DBTX, id string) (DlqEvent, error)
+	GetDispute(ctx context.Context, db DBTX, arg GetDisputeParams) (Dispute, error)
+	GetDisputeEvidence(ctx context.Context, db DBTX, disputeID string) (DisputeEvidence, error)
+	GetDocument(ctx context.Context, db DBTX, arg GetDocumentParams) (Document, error)
+	GetDocumentBySource(ctx context.Context, db DBTX, arg GetDocumentBySourceParams) (Document, error)
+	GetDocumentTemplate(ctx context.Context, db DBTX, arg GetDocumentTemplateParams) (DocumentTemplate, error)
+	GetDunningCase(ctx context.Context, db DBTX, arg GetDunningCaseParams) (DunningCase, error)
+	GetDunningCaseByInvoice(ctx context.Context, db DBTX, invoiceID string) (DunningCase, error)
+	GetEntityVersionAsOf(ctx context.Context, db DBTX, arg GetEntityVersionAsOfParams) (EntityVersion, error)
+	GetEphemeralKeyBySecretHash(ctx context.Context, db DBTX, secretHash string) (EphemeralKey, error)
+	GetFraudListEntry(ctx context.Context, db DBTX, id string) (FraudListEntry, error)
+	GetFraudReview(ctx context.Context, db DBTX, arg GetFraudReviewParams) (FraudReview, error)
+	GetHeldMutation(ctx context.Context, db DBTX, id string) (HeldMutation, error)
+	GetHoldPolicy(ctx context.Context, db DBTX, tenantID string) (HoldPolicy, error)
+	GetInvoice(ctx context.Context, db DBTX, arg GetInvoiceParams) (Invoice, error)
+	GetLastWebhookEventTime(ctx context.Context, db DBTX) (int64, error)
+	GetLatestAuditEntry(ctx context.Context, db DBTX) (AuditLog, error)
+	GetLatestChargeTransition(ctx context.Context, db DBTX, chargeID string) (ChargeTransition, error)
+	GetLatestCompletedWebhookSecretRotation(ctx context.Context, db DBTX) (WebhookSecretRotation, error)
+	GetLatestRadarScore(ctx context.Context, db DBTX, arg GetLatestRadarScoreParams) (FraudRadarScore, error)
+	GetLatestResourceAuditEntry(ctx context.Context, db DBTX, arg GetLatestResourceAuditEntryParams) (AuditLog, error)
+	GetMetadataSchema(ctx context.Context, db DBTX, arg GetMetadataSchemaParams) (MetadataSchema, error)
+	GetOffboardingArchive(ctx context.Context, db DBTX, exportID string) ([]byte, error)
+	GetOffboardingExport(ctx context.Context, db DBTX, id string) (OffboardingExport, error)
+	GetPaymentLink(ctx context.Context, db DBTX, arg GetPaymentLinkParams) (PaymentLink, error)
+	GetPaymentMethod(ctx context.Context, db DBTX, arg GetPaymentMethodParams) (PaymentMethod, error)
+	GetPaymentMethodPreference(ctx context.Context, db DBTX, arg GetPaymentMethodPreferenceParams) (PaymentMethodPreference, error)
+	GetPendingWebhookSecretRotation(ctx context.Context, db DBTX) (WebhookSecretRotation, error)
+	GetProviderCredential(ctx context.Context, db DBTX, arg GetProviderCredentialParams) (ProviderCredential, error)
+	GetQuarantine(ctx context.Context, db DBTX, id string) (Quarantine, error)
+	GetReceiptSettings(ctx context.Context, db DBTX, tenantID string) (ReceiptSetting, error)
+	GetReceivableInvoice(ctx context.Context, db DBTX, invoiceID string) (ReceivableInvoice, error)
+	GetReconciliationRun(ctx context.Context, db DBTX, id string) (ReconciliationRun, error)
+	GetRefund(ctx context.Context, db DBTX, arg GetRefundParams) (Refund, error)
+	GetRefundApproval(ctx context.Context, db DBTX, arg GetRefundApprovalParams) (RefundApproval, error)
+	GetRefundBatch(ctx context.Context, db DBTX, arg GetRefundBatchParams) (RefundBatch, error)
 	GetRefundStats(ctx context.Context, db DBTX) (GetRefundStatsRow, error)
+	GetRefundUsageByAPIKey(ctx context.Context, db DBTX, arg GetRefundUsageByAPIKeyParams) (GetRefundUsageByAPIKeyRow, error)
+	GetRefundUsageByOperator(ctx context.Context, db DBTX, arg GetRefundUsageByOperatorParams) (GetRefundUsageByOperatorRow, error)
+	GetRefundUsageByTenant(ctx context.Context, db DBTX, arg GetRefundUsageByTenantParams) (GetRefundUsageByTenantRow, error)
+	GetSimulatorObject(ctx context.Context, db DBTX, arg GetSimulatorObjectParams) (SimulatorObject, error)
+	GetSmartRoutingRule(ctx context.Context, db DBTX, id string) (SmartRoutingRule, error)
+	GetSubscription(ctx context.Context, db DBTX, arg GetSubscriptionParams) (Subscription, error)
+	GetSubscriptionPlan(ctx context.Context, db DBTX, id string) (SubscriptionPlan, error)
+	GetTenantBudget(ctx context.Context, db DBTX, tenantID string) (TenantBudget, error)
+	GetTenantConfig(ctx context.Context, db DBTX, tenantID string) (TenantConfig, error)
+	GetTenantSpend(ctx context.Context, db DBTX, arg GetTenantSpendParams) (TenantSpend, error)
+	GetUnclaimedBalance(ctx context.Context, db DBTX, arg GetUnclaimedBalanceParams) (UnclaimedBalance, error)
+	GetUsageRecord(ctx context.Context, db DBTX, id string) (UsageRecord, error)
+	GetUsageRecordByKey(ctx context.Context, db DBTX, arg GetUsageRecordByKeyParams) (UsageRecord, error)
+	GetVaultToken(ctx context.Context, db DBTX, arg GetVaultTokenParams) (VaultToken, error)
+	GetVaultTokenByProviderToken(ctx context.Context, db DBTX, arg GetVaultTokenByProviderTokenParams) (VaultToken, error)
+	GetWebhookDelivery(ctx context.Context, db DBTX, arg GetWebhookDeliveryParams) (WebhookDelivery, error)
+	GetWebhookEndpoint(ctx context.Context, db DBTX, arg GetWebhookEndpointParams) (WebhookEndpoint, error)
+	GetWebhookEvent(ctx context.Context, db DBTX, id string) (WebhookEvent, error)
+	GetWebhookSecretRotation(ctx context.Context, db DBTX, id string) (WebhookSecretRotation, error)
+	InsertOutboxEvent(ctx context.Context, db DBTX, arg InsertOutboxEventParams) error
+	InsertPaymentLinkConversion(ctx context.Context, db DBTX, arg InsertPaymentLinkConversionParams) (int64, error)
+	InsertUsageRecord(ctx context.Context, db DBTX, arg InsertUsageRecordParams) (int64, error)
+	LiftQuarantine(ctx context.Context, db DBTX, arg LiftQuarantineParams) (Quarantine, error)
+	ListAPIKeys(ctx context.Context, db DBTX) ([]ApiKey, error)
+	ListActiveCustomerHolds(ctx context.Context, db DBTX, customerID string) ([]CustomerHold, error)
+	ListActiveQuarantines(ctx context.Context, db DBTX) ([]Quarantine, error)
 	ListAllCharges(ctx context.Context, db DBTX, arg ListAllChargesParams) ([]Charge, error)
 	ListAllRefunds(ctx context.Context, db DBTX, arg ListAllRefundsParams) ([]Refund, error)
+	ListArchivedEvents(ctx context.Context, db DBTX, arg ListArchivedEventsParams) ([]EventArchive, error)
+	ListAuditEntries(ctx context.Context, db DBTX, arg ListAuditEntriesParams) ([]AuditLog, error)
+	ListAuditEntriesFrom(ctx context.Context, db DBTX, arg ListAuditEntriesFromParams) ([]AuditLog, error)
+	ListAuthorizations(ctx context.Context, db DBTX, arg ListAuthorizationsParams) ([]Authorization, error)
+	ListAutoRefundExclusions(ctx context.Context, db DBTX) ([]AutoRefundExclusion, error)
+	ListAutoRefunds(ctx context.Context, db DBTX, arg ListAutoRefundsParams) ([]AutoRefund, error)
+	ListBlocklistEntries(ctx context.Context, db DBTX, entryType string) ([]BlocklistEntry, error)
+	ListCapturedChargesCreatedBetween(ctx context.Context, db DBTX, arg ListCapturedChargesCreatedBetweenParams) ([]Charge, error)
+	ListChargeListRows(ctx context.Context, db DBTX, arg ListChargeListRowsParams) ([]ChargeListRow, error)
+	ListChargeTransitions(ctx context.Context, db DBTX, chargeID string) ([]ChargeTransition, error)
 	ListCharges(ctx context.Context, db DBTX, arg ListChargesParams) ([]Charge, error)
+	ListCompositeChargeLegs(ctx context.Context, db DBTX, compositeChargeID string) ([]CompositeChargeLeg, error)
+	ListCompositeRefundLegs(ctx context.Context, db DBTX, compositeRefundID string) ([]CompositeRefundLeg, error)
+	ListCompositeRefunds(ctx context.Context, db DBTX, arg ListCompositeRefundsParams) ([]CompositeRefund, error)
+	ListCustomerBalanceTransactions(ctx context.Context, db DBTX, arg ListCustomerBalanceTransactionsParams) ([]CustomerBalanceTransaction, error)
+	ListCustomerBalances(ctx context.Context, db DBTX, arg ListCustomerBalancesParams) ([]CustomerBalance, error)
+	ListCustomerHolds(ctx context.Context, db DBTX, arg ListCustomerHoldsParams) ([]CustomerHold, error)
+	ListCustomerIdentitiesByEmail(ctx context.Context, db DBTX, arg ListCustomerIdentitiesByEmailParams) ([]CustomerIdentity, error)
 	ListCustomers(ctx context.Context, db DBTX, arg ListCustomersParams) ([]Customer, error)
-	ListPaymentMethods(ctx context.Context, db DBTX, customerID string) ([]PaymentMethod, error)
+	ListDeadLetters(ctx context.Context, db DBTX, arg ListDeadLettersParams) ([]DlqEvent, error)
+	ListDeprecatedUsage(ctx context.Context, db DBTX) ([]DeprecatedUsage, error)
+	ListDisputeEvidenceFiles(ctx context.Context, db DBTX, disputeID string) ([]DisputeEvidenceFile, error)
+	ListDisputes(ctx context.Context, db DBTX, arg ListDisputesParams) ([]Dispute, error)
+	ListDisputesDueForEvidence(ctx context.Context, db DBTX, arg ListDisputesDueForEvidenceParams) ([]Dispute, error)
+	ListDueCustomerDeletions(ctx context.Context, db DBTX, arg ListDueCustomerDeletionsParams) ([]CustomerDeletion, error)
+	ListDueUnclaimedBalances(ctx context.Context, db DBTX, fundedAt sql.NullTime) ([]UnclaimedBalance, error)
+	ListDunningCases(ctx context.Context, db DBTX, arg ListDunningCasesParams) ([]DunningCase, error)
+	ListEntityVersions(ctx context.Context, db DBTX, arg ListEntityVersionsParams) ([]EntityVersion, error)
+	ListExpiredPaymentLinks(ctx context.Context, db DBTX, expiresAt sql.NullTime) ([]PaymentLink, error)
+	ListFraudListEntries(ctx context.Context, db DBTX, list string) ([]FraudListEntry, error)
+	ListFraudReviews(ctx context.Context, db DBTX, arg ListFraudReviewsParams) ([]FraudReview, error)
+	ListHeldMutations(ctx context.Context, db DBTX, status string) ([]HeldMutation, error)
+	ListInvoiceReminderOffsets(ctx context.Context, db DBTX, invoiceID string) ([]int32, error)
+	ListInvoices(ctx context.Context, db DBTX, arg ListInvoicesParams) ([]Invoice, error)
+	ListJobRuns(ctx context.Context, db DBTX, arg ListJobRunsParams) ([]JobRun, error)
+	ListLedgerAccountTotals(ctx context.Context, db DBTX, asOf sql.NullTime) ([]ListLedgerAccountTotalsRow, error)
+	ListLedgerEntriesByAccount(ctx context.Context, db DBTX, arg ListLedgerEntriesByAccountParams) ([]LedgerEntry, error)
+	ListLedgerEntriesByReference(ctx context.Context, db DBTX, arg ListLedgerEntriesByReferenceParams) ([]LedgerEntry, error)
+	ListMetadataSchemas(ctx context.Context, db DBTX, tenantID string) ([]MetadataSchema, error)
+	ListOffboardingExports(ctx context.Context, db DBTX, arg ListOffboardingExportsParams) ([]OffboardingExport, error)
+	ListOpenDunningCases(ctx context.Context, db DBTX, limit int32) ([]DunningCase, error)
+	ListOpenReceivableInvoices(ctx context.Context, db DBTX) ([]ReceivableInvoice, error)
+	ListOutboxEvents(ctx context.Context, db DBTX, limit int32) ([]EventOutbox, error)
+	ListOverdueReceivableInvoices(ctx context.Context, db DBTX, arg ListOverdueReceivableInvoicesParams) ([]ReceivableInvoice, error)
+	ListPaymentLinkConversions(ctx context.Context, db DBTX, arg ListPaymentLinkConversionsParams) ([]PaymentLinkConversion, error)
+	ListPaymentLinks(ctx context.Context, db DBTX, arg ListPaymentLinksParams) ([]PaymentLink, error)
+	ListPaymentMethods(ctx context.Context, db DBTX, arg ListPaymentMethodsParams) ([]PaymentMethod, error)
+	ListPendingRefundApprovals(ctx context.Context, db DBTX, tenantID string) ([]RefundApproval, error)
+	ListProviderCredentialAudit(ctx context.Context, db DBTX, arg ListProviderCredentialAuditParams) ([]ProviderCredentialAudit, error)
+	ListProviderCredentials(ctx context.Context, db DBTX, tenantID string) ([]ProviderCredential, error)
+	ListQuarantineActivity(ctx context.Context, db DBTX, arg ListQuarantineActivityParams) ([]QuarantineActivity, error)
+	ListReconciliationMismatches(ctx context.Context, db DBTX, runID string) ([]ReconciliationMismatch, error)
+	ListReconciliationRuns(ctx context.Context, db DBTX, limit int32) ([]ReconciliationRun, error)
+	ListRefundBatchItems(ctx context.Context, db DBTX, batchID string) ([]RefundBatchItem, error)
 	ListRefunds(ctx context.Context, db DBTX, arg ListRefundsParams) ([]Refund, error)
+	ListRunnableOffboardingExports(ctx context.Context, db DBTX, limit int32) ([]OffboardingExport, error)
+	ListRunnableRefundBatches(ctx context.Context, db DBTX, arg ListRunnableRefundBatchesParams) ([]RefundBatch, error)
+	ListSimulatorObjects(ctx context.Context, db DBTX, arg ListSimulatorObjectsParams) ([]SimulatorObject, error)
+	ListSmartRoutingRules(ctx context.Context, db DBTX) ([]SmartRoutingRule, error)
+	ListStaleAuthorizations(ctx context.Context, db DBTX, arg ListStaleAuthorizationsParams) ([]Authorization, error)
+	ListSubscriptionPlans(ctx context.Context, db DBTX, productID string) ([]SubscriptionPlan, error)
+	ListSubscriptions(ctx context.Context, db DBTX, arg ListSubscriptionsParams) ([]Subscription, error)
+	ListSucceededRefundsCreatedBetween(ctx context.Context, db DBTX, arg ListSucceededRefundsCreatedBetweenParams) ([]Refund, error)
+	ListTenantConfigs(ctx context.Context, db DBTX) ([]TenantConfig, error)
+	ListTenantCustomerIDs(ctx context.Context, db DBTX, tenantID string) ([]string, error)
+	ListTenantCustomers(ctx context.Context, db DBTX, arg ListTenantCustomersParams) ([]Customer, error)
+	ListTokenizedPaymentMethods(ctx context.Context, db DBTX, customerID string) ([]string, error)
+	ListUnreportedUsageRecords(ctx context.Context, db DBTX, arg ListUnreportedUsageRecordsParams) ([]UsageRecord, error)
+	ListUsageRecords(ctx context.Context, db DBTX, arg ListUsageRecordsParams) ([]UsageRecord, error)
+	ListVaultTokensByCustomer(ctx context.Context, db DBTX, arg ListVaultTokensByCustomerParams) ([]VaultToken, error)
+	ListWebhookDeliveries(ctx context.Context, db DBTX, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	ListWebhookDeliveryAttempts(ctx context.Context, db DBTX, deliveryID string) ([]WebhookDeliveryAttempt, error)
+	ListWebhookEndpoints(ctx context.Context, db DBTX, tenantID string) ([]WebhookEndpoint, error)
+	ListWebhookSecretRotations(ctx context.Context, db DBTX, limit int32) ([]WebhookSecretRotation, error)
+	MarkCustomerEmailVerified(ctx context.Context, db DBTX, arg MarkCustomerEmailVerifiedParams) (CustomerIdentity, error)
+	MarkDisputeEvidenceSubmitted(ctx context.Context, db DBTX, arg MarkDisputeEvidenceSubmittedParams) (DisputeEvidence, error)
+	MarkMirroredChargeDisputed(ctx context.Context, db DBTX, id string) error
+	MarkReceivableInvoiceOverdue(ctx context.Context, db DBTX, arg MarkReceivableInvoiceOverdueParams) error
+	MarkReceivableInvoicePaid(ctx context.Context, db DBTX, arg MarkReceivableInvoicePaidParams) (ReceivableInvoice, error)
+	MarkUsageRecordReported(ctx context.Context, db DBTX, arg MarkUsageRecordReportedParams) (UsageRecord, error)
+	MatchFraudListEntries(ctx context.Context, db DBTX, arg MatchFraudListEntriesParams) ([]FraudListEntry, error)
+	OpenDunningCase(ctx context.Context, db DBTX, arg OpenDunningCaseParams) (int64, error)
+	PurgeArchivedEvents(ctx context.Context, db DBTX, occurredAt time.Time) (int64, error)
+	PurgeDeadLetters(ctx context.Context, db DBTX, arg PurgeDeadLettersParams) (int64, error)
+	RecordBudgetAlert(ctx context.Context, db DBTX, arg RecordBudgetAlertParams) (int64, error)
+	RecordChargeCredential(ctx context.Context, db DBTX, arg RecordChargeCredentialParams) error
+	RecordCustomerErasure(ctx context.Context, db DBTX, arg RecordCustomerErasureParams) (CustomerErasure, error)
+	RecordDeprecatedUsage(ctx context.Context, db DBTX, arg RecordDeprecatedUsageParams) error
+	RecordEntityVersion(ctx context.Context, db DBTX, arg RecordEntityVersionParams) error
+	RecordFraudReviewCharge(ctx context.Context, db DBTX, arg RecordFraudReviewChargeParams) (FraudReview, error)
+	RecordFraudScreening(ctx context.Context, db DBTX, arg RecordFraudScreeningParams) error
+	RecordHeldMutationResult(ctx context.Context, db DBTX, arg RecordHeldMutationResultParams) (HeldMutation, error)
+	RecordInvoiceReminder(ctx context.Context, db DBTX, arg RecordInvoiceReminderParams) error
+	RecordOffboardingPANMigration(ctx context.Context, db DBTX, arg RecordOffboardingPANMigrationParams) (OffboardingExport, error)
+	RecordQuarantineActivity(ctx context.Context, db DBTX, arg RecordQuarantineActivityParams) error
+	RecordRadarScore(ctx context.Context, db DBTX, arg RecordRadarScoreParams) error
+	RecordRefundActivity(ctx context.Context, db DBTX, arg RecordRefundActivityParams) error
+	RecordRefundBatchItem(ctx context.Context, db DBTX, arg RecordRefundBatchItemParams) error
+	RecordRoutedCharge(ctx context.Context, db DBTX, arg RecordRoutedChargeParams) error
+	RecordUsageReportFailure(ctx context.Context, db DBTX, arg RecordUsageReportFailureParams) error
+	RecordWebhookEvent(ctx context.Context, db DBTX, arg RecordWebhookEventParams) error
+	RecordWebhookSecretRotationDelivery(ctx context.Context, db DBTX, id string) (WebhookSecretRotation, error)
+	ReleaseCustomerDeletion(ctx context.Context, db DBTX, customerID string) error
+	ReleaseCustomerHold(ctx context.Context, db DBTX, arg ReleaseCustomerHoldParams) (CustomerHold, error)
+	ReleaseRefundApproval(ctx context.Context, db DBTX, arg ReleaseRefundApprovalParams) error
+	RemapVaultToken(ctx context.Context, db DBTX, arg RemapVaultTokenParams) (VaultToken, error)
+	RevokeAPIKey(ctx context.Context, db DBTX, arg RevokeAPIKeyParams) (int64, error)
+	RevokeEphemeralKey(ctx context.Context, db DBTX, arg RevokeEphemeralKeyParams) (int64, error)
+	SaveDisputeEvidence(ctx context.Context, db DBTX, arg SaveDisputeEvidenceParams) (DisputeEvidence, error)
+	SaveSimulatorObject(ctx context.Context, db DBTX, arg SaveSimulatorObjectParams) error
+	SearchTenantCharges(ctx context.Context, db DBTX, arg SearchTenantChargesParams) ([]Charge, error)
+	SearchTenantCustomers(ctx context.Context, db DBTX, arg SearchTenantCustomersParams) ([]Customer, error)
+	SetBlocklistEntryProviderItem(ctx context.Context, db DBTX, arg SetBlocklistEntryProviderItemParams) error
+	SetCustomerBalanceTransactionSource(ctx context.Context, db DBTX, arg SetCustomerBalanceTransactionSourceParams) error
+	SetCustomerVerificationToken(ctx context.Context, db DBTX, arg SetCustomerVerificationTokenParams) error
+	StoreOffboardingArchive(ctx context.Context, db DBTX, arg StoreOffboardingArchiveParams) error
+	SumCustomerBalanceTransactionsBySource(ctx context.Context, db DBTX, arg SumCustomerBalanceTransactionsBySourceParams) (int64, error)
+	SummarizeRoutedCharges(ctx context.Context, db DBTX, arg SummarizeRoutedChargesParams) ([]SummarizeRoutedChargesRow, error)
+	TrackAuthorization(ctx context.Context, db DBTX, arg TrackAuthorizationParams) (int64, error)
+	UpdateAuthorization(ctx context.Context, db DBTX, arg UpdateAuthorizationParams) (Authorization, error)
+	UpdateChargeListRowRefund(ctx context.Context, db DBTX, arg UpdateChargeListRowRefundParams) error
+	UpdateChargeListRowsCustomer(ctx context.Context, db DBTX, arg UpdateChargeListRowsCustomerParams) error
 	UpdateChargeStatus(ctx context.Context, db DBTX, arg UpdateChargeStatusParams) (Charge, error)
+	UpdateCompositeRefundLeg(ctx context.Context, db DBTX, arg UpdateCompositeRefundLegParams) (CompositeRefundLeg, error)
+	UpdateCompositeRefundStatus(ctx context.Context, db DBTX, arg UpdateCompositeRefundStatusParams) error
 	UpdateCustomer(ctx context.Context, db DBTX, arg UpdateCustomerParams) (Customer, error)
+	UpdateCustomerIdentity(ctx context.Context, db DBTX, arg UpdateCustomerIdentityParams) (CustomerIdentity, error)
+	UpdateDeadLetter(ctx context.Context, db DBTX, arg UpdateDeadLetterParams) (DlqEvent, error)
+	UpdateDunningCase(ctx context.Context, db DBTX, arg UpdateDunningCaseParams) (DunningCase, error)
 	UpdateRefundStatus(ctx context.Context, db DBTX, arg UpdateRefundStatusParams) (Refund, error)
+	UpdateSmartRoutingRule(ctx context.Context, db DBTX, arg UpdateSmartRoutingRuleParams) (SmartRoutingRule, error)
+	UpdateVaultTokenFingerprint(ctx context.Context, db DBTX, arg UpdateVaultTokenFingerprintParams) error
+	UpdateWebhookDelivery(ctx context.Context, db DBTX, arg UpdateWebhookDeliveryParams) (WebhookDelivery, error)
+	UpdateWebhookEndpoint(ctx context.Context, db DBTX, arg UpdateWebhookEndpointParams) (WebhookEndpoint, error)
+	UpsertAutoRefundExclusion(ctx context.Context, db DBTX, arg UpsertAutoRefundExclusionParams) (AutoRefundExclusion, error)
+	UpsertChargeListRow(ctx context.Context, db DBTX, arg UpsertChargeListRowParams) error
+	UpsertCustomFieldDefinition(ctx context.Context, db DBTX, arg UpsertCustomFieldDefinitionParams) (CustomFieldDefinition, error)
+	UpsertDispute(ctx context.Context, db DBTX, arg UpsertDisputeParams) error
+	UpsertDocument(ctx context.Context, db DBTX, arg UpsertDocumentParams) (Document, error)
+	UpsertDocumentTemplate(ctx context.Context, db DBTX, arg UpsertDocumentTemplateParams) (DocumentTemplate, error)
+	UpsertHoldPolicy(ctx context.Context, db DBTX, arg UpsertHoldPolicyParams) (HoldPolicy, error)
+	UpsertInvoice(ctx context.Context, db DBTX, arg UpsertInvoiceParams) error
+	UpsertMetadataSchema(ctx context.Context, db DBTX, arg UpsertMetadataSchemaParams) (MetadataSchema, error)
+	UpsertMirroredCharge(ctx context.Context, db DBTX, arg UpsertMirroredChargeParams) error
+	UpsertMirroredCustomer(ctx context.Context, db DBTX, arg UpsertMirroredCustomerParams) error
+	UpsertMirroredPaymentMethod(ctx context.Context, db DBTX, arg UpsertMirroredPaymentMethodParams) error
+	UpsertMirroredRefund(ctx context.Context, db DBTX, arg UpsertMirroredRefundParams) error
+	UpsertPaymentMethodPreference(ctx context.Context, db DBTX, arg UpsertPaymentMethodPreferenceParams) (PaymentMethodPreference, error)
+	UpsertProviderCredential(ctx context.Context, db DBTX, arg UpsertProviderCredentialParams) (ProviderCredential, error)
+	UpsertReceiptSettings(ctx context.Context, db DBTX, arg UpsertReceiptSettingsParams) (ReceiptSetting, error)
+	UpsertReceivableInvoice(ctx context.Context, db DBTX, arg UpsertReceivableInvoiceParams) (ReceivableInvoice, error)
+	UpsertSubscription(ctx context.Context, db DBTX, arg UpsertSubscriptionParams) error
+	UpsertSubscriptionPlan(ctx context.Context, db DBTX, arg UpsertSubscriptionPlanParams) error
+	UpsertTenantBudget(ctx context.Context, db DBTX, arg UpsertTenantBudgetParams) (TenantBudget, error)
+	UpsertTenantConfig(ctx context.Context, db DBTX, arg UpsertTenantConfigParams) (TenantConfig, error)
+	UpsertUnclaimedBalance(ctx context.Context, db DBTX, arg UpsertUnclaimedBalanceParams) error
 }
 
 var _ Querier = (*Queries)(nil)
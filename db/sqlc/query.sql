@@ -7,7 +7,7 @@ INSERT INTO customers (
 
 -- name: GetCustomer :one
 SELECT * FROM customers
-WHERE id = $1 LIMIT 1;
+WHERE id = $1 AND ($2 = '' OR tenant_id = $2) LIMIT 1;
 
 -- name: GetCustomerByEmail :one
 SELECT * FROM customers
@@ -37,11 +37,11 @@ INSERT INTO payment_methods (
 
 -- name: GetPaymentMethod :one
 SELECT * FROM payment_methods
-WHERE id = $1 LIMIT 1;
+WHERE id = $1 AND ($2 = '' OR tenant_id = $2) LIMIT 1;
 
 -- name: ListPaymentMethods :many
 SELECT * FROM payment_methods
-WHERE customer_id = $1
+WHERE customer_id = $1 AND ($2 = '' OR tenant_id = $2)
 ORDER BY created_at DESC;
 
 -- name: DeletePaymentMethod :exec
@@ -57,11 +57,11 @@ INSERT INTO charges (
 
 -- name: GetCharge :one
 SELECT * FROM charges
-WHERE id = $1 LIMIT 1;
+WHERE id = $1 AND ($2 = '' OR tenant_id = $2) LIMIT 1;
 
 -- name: ListCharges :many
 SELECT * FROM charges
-WHERE customer_id = $1
+WHERE customer_id = $1 AND ($4 = '' OR tenant_id = $4)
 ORDER BY created_at DESC
 LIMIT $2 OFFSET $3;
 
@@ -100,11 +100,11 @@ INSERT INTO refunds (
 
 -- name: GetRefund :one
 SELECT * FROM refunds
-WHERE id = $1 LIMIT 1;
+WHERE id = $1 AND ($2 = '' OR tenant_id = $2) LIMIT 1;
 
 -- name: ListRefunds :many
 SELECT * FROM refunds
-WHERE charge_id = $1
+WHERE charge_id = $1 AND ($4 = '' OR tenant_id = $4)
 ORDER BY created_at DESC
 LIMIT $2 OFFSET $3;
 
@@ -126,3 +126,2184 @@ SELECT
     COUNT(CASE WHEN status = 'succeeded' THEN 1 END) as successful_refunds,
     SUM(CASE WHEN status = 'succeeded' THEN amount ELSE 0 END) as successful_amount
 FROM refunds;
+
+-- name: GetHoldPolicy :one
+SELECT * FROM hold_policies
+WHERE tenant_id = $1 LIMIT 1;
+
+-- name: UpsertHoldPolicy :one
+INSERT INTO hold_policies (
+    tenant_id, pause_on_dispute, pause_on_fraud, block_charges_on_dispute, block_charges_on_fraud, min_risk_score, auto_release
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+ON CONFLICT (tenant_id) DO UPDATE
+SET pause_on_dispute = EXCLUDED.pause_on_dispute,
+    pause_on_fraud = EXCLUDED.pause_on_fraud,
+    block_charges_on_dispute = EXCLUDED.block_charges_on_dispute,
+    block_charges_on_fraud = EXCLUDED.block_charges_on_fraud,
+    min_risk_score = EXCLUDED.min_risk_score,
+    auto_release = EXCLUDED.auto_release,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: CreateCustomerHold :one
+INSERT INTO customer_holds (
+    id, tenant_id, customer_id, reason, source_id, status, blocks_charges, paused_subscriptions
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING *;
+
+-- name: GetCustomerHold :one
+SELECT * FROM customer_holds
+WHERE id = $1 AND ($2 = '' OR tenant_id = $2) LIMIT 1;
+
+-- name: GetActiveCustomerHoldBySource :one
+SELECT * FROM customer_holds
+WHERE source_id = $1 AND status = 'active'
+LIMIT 1;
+
+-- name: ListActiveCustomerHolds :many
+SELECT * FROM customer_holds
+WHERE customer_id = $1 AND status = 'active'
+ORDER BY created_at DESC;
+
+-- name: ListCustomerHolds :many
+SELECT * FROM customer_holds
+WHERE customer_id = $1 AND ($2 = '' OR tenant_id = $2)
+ORDER BY created_at DESC;
+
+-- name: ReleaseCustomerHold :one
+UPDATE customer_holds
+SET status = 'released', release_reason = $2, released_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND status = 'active' AND ($3 = '' OR tenant_id = $3)
+RETURNING *;
+
+-- name: RecordRefundActivity :exec
+INSERT INTO refund_activity (
+    id, tenant_id, api_key_id, operator_id, refund_id, charge_id, amount, currency
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+);
+
+-- name: GetRefundUsageByTenant :one
+SELECT COUNT(*) AS refund_count, COALESCE(SUM(amount), 0)::bigint AS refund_amount
+FROM refund_activity
+WHERE tenant_id = $1 AND created_at >= $2;
+
+-- name: GetRefundUsageByAPIKey :one
+SELECT COUNT(*) AS refund_count, COALESCE(SUM(amount), 0)::bigint AS refund_amount
+FROM refund_activity
+WHERE api_key_id = $1 AND created_at >= $2;
+
+-- name: GetRefundUsageByOperator :one
+SELECT COUNT(*) AS refund_count, COALESCE(SUM(amount), 0)::bigint AS refund_amount
+FROM refund_activity
+WHERE operator_id = $1 AND created_at >= $2;
+
+-- name: CreateRefundApproval :one
+INSERT INTO refund_approvals (
+    id, tenant_id, api_key_id, operator_id, request, amount, reasons, status
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING *;
+
+-- name: GetRefundApproval :one
+SELECT * FROM refund_approvals
+WHERE id = $1 AND ($2 = '' OR tenant_id = $2) LIMIT 1;
+
+-- name: ListPendingRefundApprovals :many
+SELECT * FROM refund_approvals
+WHERE tenant_id = $1 AND status = 'pending'
+ORDER BY created_at ASC;
+
+-- name: ClaimRefundApproval :execrows
+UPDATE refund_approvals
+SET status = 'approving', decided_by = $2, updated_at = NOW()
+WHERE id = $1 AND status = 'pending' AND ($3 = '' OR tenant_id = $3);
+
+-- name: ReleaseRefundApproval :exec
+UPDATE refund_approvals
+SET status = 'pending', decided_by = NULL, updated_at = NOW()
+WHERE id = $1 AND status = 'approving' AND ($2 = '' OR tenant_id = $2);
+
+-- name: DecideRefundApproval :one
+UPDATE refund_approvals
+SET status = $2, decided_by = $3, refund_id = $4, decided_at = NOW(), updated_at = NOW()
+WHERE id = $1
+  AND status = CASE WHEN $2 = 'approved' THEN 'approving' ELSE 'pending' END
+  AND ($5 = '' OR tenant_id = $5)
+RETURNING *;
+
+-- name: GetWebhookEvent :one
+SELECT * FROM webhook_events
+WHERE id = $1 LIMIT 1;
+
+-- name: RecordWebhookEvent :exec
+INSERT INTO webhook_events (
+    id, type, created, source, payload
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (id) DO NOTHING;
+
+-- name: GetLastWebhookEventTime :one
+SELECT COALESCE(MAX(created), 0)::bigint AS last_created
+FROM webhook_events;
+
+-- name: RecordChargeCredential :exec
+INSERT INTO charge_credentials (
+    charge_id, tenant_id, customer_id, payment_method_id, network, credential_type,
+    wallet, amount, currency, status, failure_code
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+)
+ON CONFLICT (charge_id) DO UPDATE SET
+    status = EXCLUDED.status,
+    failure_code = EXCLUDED.failure_code;
+
+-- name: GetChargeCredentialStats :many
+SELECT credential_type, network, wallet,
+    COUNT(*) AS charge_count,
+    COUNT(*) FILTER (WHERE status = 'succeeded') AS succeeded_count,
+    COALESCE(SUM(amount) FILTER (WHERE status = 'succeeded'), 0)::bigint AS succeeded_amount
+FROM charge_credentials
+WHERE tenant_id = $1 AND currency = $2 AND created_at >= $3
+GROUP BY credential_type, network, wallet
+ORDER BY credential_type, network, wallet;
+
+-- name: ListTokenizedPaymentMethods :many
+SELECT payment_method_id FROM charge_credentials
+WHERE customer_id = $1 AND credential_type = 'network_token'
+    AND status = 'succeeded' AND payment_method_id <> ''
+GROUP BY payment_method_id;
+
+-- name: GetMetadataSchema :one
+SELECT * FROM metadata_schemas
+WHERE tenant_id = $1 AND resource = $2 LIMIT 1;
+
+-- name: ListMetadataSchemas :many
+SELECT * FROM metadata_schemas
+WHERE tenant_id = $1
+ORDER BY resource;
+
+-- name: UpsertMetadataSchema :one
+INSERT INTO metadata_schemas (
+    tenant_id, resource, schema
+) VALUES (
+    $1, $2, $3
+)
+ON CONFLICT (tenant_id, resource) DO UPDATE
+SET schema = EXCLUDED.schema
+RETURNING *;
+
+-- name: DeleteMetadataSchema :execrows
+DELETE FROM metadata_schemas
+WHERE tenant_id = $1 AND resource = $2;
+
+-- name: UpsertChargeListRow :exec
+INSERT INTO charge_list_rows (
+    charge_id, tenant_id, customer_id, customer_email, customer_name, plan_name,
+    amount, amount_refunded, currency, status, description, charge_created
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+)
+ON CONFLICT (charge_id) DO UPDATE
+SET tenant_id = EXCLUDED.tenant_id,
+    customer_id = EXCLUDED.customer_id,
+    customer_email = EXCLUDED.customer_email,
+    customer_name = EXCLUDED.customer_name,
+    plan_name = EXCLUDED.plan_name,
+    amount = EXCLUDED.amount,
+    amount_refunded = EXCLUDED.amount_refunded,
+    currency = EXCLUDED.currency,
+    status = EXCLUDED.status,
+    description = EXCLUDED.description;
+
+-- name: UpdateChargeListRowRefund :exec
+UPDATE charge_list_rows
+SET last_refund_id = $2,
+    last_refund_status = $3
+WHERE charge_id = $1;
+
+-- name: UpdateChargeListRowsCustomer :exec
+UPDATE charge_list_rows
+SET customer_email = $2,
+    customer_name = $3
+WHERE customer_id = $1
+  AND NOT EXISTS (SELECT 1 FROM customer_erasures WHERE customer_erasures.customer_id = charge_list_rows.customer_id);
+
+-- name: ListChargeListRows :many
+SELECT * FROM charge_list_rows
+WHERE tenant_id = $1
+    AND ($2 = '' OR customer_id = $2)
+    AND ($3 = '' OR status = $3)
+ORDER BY charge_created DESC
+LIMIT $4 OFFSET $5;
+
+-- name: RecordEntityVersion :exec
+INSERT INTO entity_versions (
+    entity_type, entity_id, status, state, event_id, event_type, valid_from
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+ON CONFLICT (entity_type, entity_id, event_id) DO NOTHING;
+
+-- name: ListEntityVersions :many
+SELECT * FROM entity_versions
+WHERE entity_type = $1 AND entity_id = $2
+ORDER BY valid_from, id;
+
+-- name: GetEntityVersionAsOf :one
+SELECT * FROM entity_versions
+WHERE entity_type = $1 AND entity_id = $2 AND valid_from <= $3
+ORDER BY valid_from DESC, id DESC
+LIMIT 1;
+
+-- name: RecordDeprecatedUsage :exec
+INSERT INTO deprecated_usage (
+    notice_id, api_key_id, request_count, first_seen, last_seen
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (notice_id, api_key_id) DO UPDATE SET
+    request_count = deprecated_usage.request_count + EXCLUDED.request_count,
+    first_seen = LEAST(deprecated_usage.first_seen, EXCLUDED.first_seen),
+    last_seen = GREATEST(deprecated_usage.last_seen, EXCLUDED.last_seen);
+
+-- name: ListDeprecatedUsage :many
+SELECT * FROM deprecated_usage
+ORDER BY notice_id, last_seen DESC;
+
+-- name: CreateLedgerEntry :exec
+INSERT INTO ledger_entries (
+    id, debit_account, credit_account, amount, currency, reference_type, reference_id, description
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+ON CONFLICT (reference_type, reference_id, debit_account, credit_account) DO NOTHING;
+
+-- name: ListLedgerEntriesByReference :many
+SELECT * FROM ledger_entries
+WHERE reference_type = $1 AND reference_id = $2
+ORDER BY created_at;
+
+-- name: ListLedgerEntriesByAccount :many
+SELECT * FROM ledger_entries
+WHERE (debit_account = sqlc.arg(account) OR credit_account = sqlc.arg(account))
+  AND (sqlc.arg(currency)::text = '' OR currency = sqlc.arg(currency))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(limit) OFFSET sqlc.arg(offset);
+
+-- name: ListLedgerAccountTotals :many
+SELECT account::text AS account, currency, COALESCE(SUM(debits), 0)::bigint AS debits, COALESCE(SUM(credits), 0)::bigint AS credits
+FROM (
+    SELECT debit_account AS account, currency, amount AS debits, 0 AS credits FROM ledger_entries WHERE created_at <= sqlc.arg(as_of)
+    UNION ALL
+    SELECT credit_account AS account, currency, 0 AS debits, amount AS credits FROM ledger_entries WHERE created_at <= sqlc.arg(as_of)
+) AS postings
+GROUP BY account, currency
+ORDER BY currency, account;
+
+-- name: GetUnclaimedBalance :one
+SELECT * FROM unclaimed_balances
+WHERE customer_id = $1 AND currency = $2 LIMIT 1;
+
+-- name: UpsertUnclaimedBalance :exec
+INSERT INTO unclaimed_balances (
+    customer_id, currency, amount, funded_at
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (customer_id, currency) DO UPDATE SET
+    amount = EXCLUDED.amount,
+    funded_at = EXCLUDED.funded_at;
+
+-- name: ListDueUnclaimedBalances :many
+SELECT * FROM unclaimed_balances
+WHERE amount > 0 AND funded_at <= $1
+ORDER BY funded_at;
+
+-- name: CreateAutoRefund :one
+INSERT INTO auto_refunds (
+    id, customer_id, currency, amount, refund_id, status, failure_reason, funded_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING *;
+
+-- name: ListAutoRefunds :many
+SELECT * FROM auto_refunds
+WHERE $1 = '' OR customer_id = $1
+ORDER BY created_at DESC
+LIMIT $2;
+
+-- name: GetAutoRefundExclusion :one
+SELECT * FROM auto_refund_exclusions
+WHERE customer_id = $1 LIMIT 1;
+
+-- name: ListAutoRefundExclusions :many
+SELECT * FROM auto_refund_exclusions
+ORDER BY created_at DESC;
+
+-- name: UpsertAutoRefundExclusion :one
+INSERT INTO auto_refund_exclusions (
+    customer_id, reason
+) VALUES (
+    $1, $2
+)
+ON CONFLICT (customer_id) DO UPDATE SET
+    reason = EXCLUDED.reason
+RETURNING *;
+
+-- name: DeleteAutoRefundExclusion :execrows
+DELETE FROM auto_refund_exclusions
+WHERE customer_id = $1;
+
+-- name: GetReceivableInvoice :one
+SELECT * FROM receivable_invoices
+WHERE invoice_id = $1 LIMIT 1;
+
+-- name: UpsertReceivableInvoice :one
+INSERT INTO receivable_invoices (
+    invoice_id, customer_id, subscription_id, number, amount_due, amount_remaining, currency, due_date, status
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+ON CONFLICT (invoice_id) DO UPDATE SET
+    number = EXCLUDED.number,
+    amount_due = EXCLUDED.amount_due,
+    amount_remaining = EXCLUDED.amount_remaining,
+    due_date = EXCLUDED.due_date,
+    status = EXCLUDED.status
+RETURNING *;
+
+-- name: ListOpenReceivableInvoices :many
+SELECT * FROM receivable_invoices
+WHERE status = 'open'
+ORDER BY due_date;
+
+-- name: ListOverdueReceivableInvoices :many
+SELECT * FROM receivable_invoices
+WHERE status = 'open' AND due_date < $1 AND ($2 = '' OR customer_id = $2)
+ORDER BY due_date;
+
+-- name: MarkReceivableInvoiceOverdue :exec
+UPDATE receivable_invoices
+SET overdue_at = $2
+WHERE invoice_id = $1 AND overdue_at IS NULL;
+
+-- name: MarkReceivableInvoicePaid :one
+UPDATE receivable_invoices
+SET status = 'paid', amount_remaining = 0, paid_at = $2, paid_reference = $3
+WHERE invoice_id = $1
+RETURNING *;
+
+-- name: ListInvoiceReminderOffsets :many
+SELECT offset_days FROM invoice_reminders
+WHERE invoice_id = $1
+ORDER BY offset_days;
+
+-- name: RecordInvoiceReminder :exec
+INSERT INTO invoice_reminders (
+    invoice_id, offset_days
+) VALUES (
+    $1, $2
+)
+ON CONFLICT (invoice_id, offset_days) DO NOTHING;
+
+-- name: CreateVaultToken :one
+INSERT INTO vault_tokens (
+    id, provider, provider_token, customer_id, type, fingerprint, tenant_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+ON CONFLICT (provider, provider_token) DO UPDATE
+SET customer_id = EXCLUDED.customer_id
+RETURNING *;
+
+-- name: GetVaultToken :one
+SELECT * FROM vault_tokens
+WHERE id = $1 AND ($2 = '' OR tenant_id = $2);
+
+-- name: GetVaultTokenByProviderToken :one
+SELECT * FROM vault_tokens
+WHERE provider = $1 AND provider_token = $2;
+
+-- name: ListVaultTokensByCustomer :many
+SELECT * FROM vault_tokens
+WHERE customer_id = $1 AND ($2 = '' OR tenant_id = $2)
+ORDER BY created_at;
+
+-- name: RemapVaultToken :one
+UPDATE vault_tokens
+SET provider = $2, provider_token = $3
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateVaultTokenFingerprint :exec
+UPDATE vault_tokens
+SET fingerprint = $3
+WHERE provider = $1 AND provider_token = $2;
+
+-- name: DeleteVaultToken :exec
+DELETE FROM vault_tokens
+WHERE id = $1;
+
+-- name: RecordRoutedCharge :exec
+INSERT INTO routed_charges (
+    charge_id, provider, currency, amount, settlement_currency, reason
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+ON CONFLICT (charge_id) DO NOTHING;
+
+-- name: SummarizeRoutedCharges :many
+SELECT provider, currency, settlement_currency, COUNT(*) AS charge_count, COALESCE(SUM(amount), 0)::bigint AS total_amount
+FROM routed_charges
+WHERE created_at >= sqlc.arg(created_from) AND created_at < sqlc.arg(created_to)
+GROUP BY provider, currency, settlement_currency
+ORDER BY provider, currency;
+
+-- name: UpsertDispute :exec
+INSERT INTO disputes (
+    id, charge_id, payment_intent_id, amount, currency, reason, status,
+    network_reason_code, is_charge_refundable, evidence, evidence_due_by,
+    has_evidence, past_due, submission_count, payment_method_type, card_brand,
+    card_network_reason_code, balance_transactions, livemode, metadata,
+    disputed_at, synced_at, tenant_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22,
+    COALESCE(NULLIF($23, ''), (SELECT tenant_id FROM charges WHERE charges.id = $2), 'default')
+)
+ON CONFLICT (id) DO UPDATE
+SET charge_id = EXCLUDED.charge_id,
+    payment_intent_id = EXCLUDED.payment_intent_id,
+    amount = EXCLUDED.amount,
+    currency = EXCLUDED.currency,
+    reason = EXCLUDED.reason,
+    status = EXCLUDED.status,
+    network_reason_code = EXCLUDED.network_reason_code,
+    is_charge_refundable = EXCLUDED.is_charge_refundable,
+    evidence = EXCLUDED.evidence,
+    evidence_due_by = EXCLUDED.evidence_due_by,
+    has_evidence = EXCLUDED.has_evidence,
+    past_due = EXCLUDED.past_due,
+    submission_count = EXCLUDED.submission_count,
+    payment_method_type = EXCLUDED.payment_method_type,
+    card_brand = EXCLUDED.card_brand,
+    card_network_reason_code = EXCLUDED.card_network_reason_code,
+    balance_transactions = EXCLUDED.balance_transactions,
+    livemode = EXCLUDED.livemode,
+    metadata = EXCLUDED.metadata,
+    synced_at = EXCLUDED.synced_at
+WHERE disputes.synced_at <= EXCLUDED.synced_at;
+
+-- name: GetDispute :one
+SELECT * FROM disputes
+WHERE id = $1 AND ($2 = '' OR tenant_id = $2);
+
+-- name: ListDisputes :many
+SELECT * FROM disputes
+WHERE ($1 = '' OR charge_id = $1) AND ($2 = '' OR status = $2) AND ($4 = '' OR tenant_id = $4)
+ORDER BY disputed_at DESC
+LIMIT $3;
+
+-- name: CreateEphemeralKey :one
+INSERT INTO ephemeral_keys (
+    id, customer_id, secret_hash, scopes, issued_by, expires_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+RETURNING *;
+
+-- name: GetEphemeralKeyBySecretHash :one
+SELECT * FROM ephemeral_keys
+WHERE secret_hash = $1;
+
+-- name: RevokeEphemeralKey :execrows
+UPDATE ephemeral_keys
+SET revoked_at = $2
+WHERE id = $1 AND revoked_at IS NULL;
+
+-- name: GetTenantBudget :one
+SELECT * FROM tenant_budgets
+WHERE tenant_id = $1;
+
+-- name: UpsertTenantBudget :one
+INSERT INTO tenant_budgets (
+    tenant_id, monthly_limit, thresholds, hard_stop, updated_by
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (tenant_id) DO UPDATE
+SET monthly_limit = EXCLUDED.monthly_limit,
+    thresholds = EXCLUDED.thresholds,
+    hard_stop = EXCLUDED.hard_stop,
+    updated_by = EXCLUDED.updated_by
+RETURNING *;
+
+-- name: DeleteTenantBudget :execrows
+DELETE FROM tenant_budgets
+WHERE tenant_id = $1;
+
+-- name: GetTenantSpend :one
+SELECT * FROM tenant_spend
+WHERE tenant_id = $1 AND period = $2;
+
+-- name: AddTenantSpend :one
+INSERT INTO tenant_spend (
+    tenant_id, period, amount, charge_count
+) VALUES (
+    $1, $2, $3, 1
+)
+ON CONFLICT (tenant_id, period) DO UPDATE
+SET amount = tenant_spend.amount + EXCLUDED.amount,
+    charge_count = tenant_spend.charge_count + 1
+RETURNING *;
+
+-- name: RecordBudgetAlert :execrows
+INSERT INTO tenant_budget_alerts (
+    tenant_id, period, threshold
+) VALUES (
+    $1, $2, $3
+)
+ON CONFLICT (tenant_id, period, threshold) DO NOTHING;
+
+-- name: CreateCustomerIdentity :one
+INSERT INTO customer_identities (
+    customer_id, tenant_id, normalized_email, name
+) VALUES (
+    $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: GetCustomerIdentity :one
+SELECT * FROM customer_identities
+WHERE customer_id = $1;
+
+-- name: ListCustomerIdentitiesByEmail :many
+SELECT * FROM customer_identities
+WHERE tenant_id = $1 AND normalized_email = $2
+ORDER BY created_at ASC;
+
+-- name: UpdateCustomerIdentity :one
+UPDATE customer_identities
+SET normalized_email = $2,
+    name = $3,
+    email_verified_at = $4
+WHERE customer_id = $1
+RETURNING *;
+
+-- name: SetCustomerVerificationToken :exec
+UPDATE customer_identities
+SET verification_token_hash = $2,
+    verification_expires_at = $3
+WHERE customer_id = $1;
+
+-- name: MarkCustomerEmailVerified :one
+UPDATE customer_identities
+SET email_verified_at = $2,
+    verification_token_hash = '',
+    verification_expires_at = NULL
+WHERE customer_id = $1
+RETURNING *;
+
+-- name: DeleteCustomerIdentity :exec
+DELETE FROM customer_identities
+WHERE customer_id = $1;
+
+-- name: CreateBlocklistEntry :one
+INSERT INTO blocklist_entries (
+    id, entry_type, value, reason, source, created_by, provider_item_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+ON CONFLICT (entry_type, value) DO UPDATE
+SET entry_type = EXCLUDED.entry_type
+RETURNING *;
+
+-- name: GetBlocklistEntry :one
+SELECT * FROM blocklist_entries
+WHERE id = $1;
+
+-- name: GetBlocklistEntryByValue :one
+SELECT * FROM blocklist_entries
+WHERE entry_type = $1 AND value = $2;
+
+-- name: ListBlocklistEntries :many
+SELECT * FROM blocklist_entries
+WHERE $1 = '' OR entry_type = $1
+ORDER BY created_at DESC;
+
+-- name: SetBlocklistEntryProviderItem :exec
+UPDATE blocklist_entries
+SET provider_item_id = $2
+WHERE id = $1;
+
+-- name: DeleteBlocklistEntry :execrows
+DELETE FROM blocklist_entries
+WHERE id = $1;
+
+-- name: AppendChargeTransition :one
+INSERT INTO charge_transitions (
+    id, charge_id, sequence, from_state, to_state, source, event_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+ON CONFLICT (charge_id, sequence) DO NOTHING
+RETURNING *;
+
+-- name: GetLatestChargeTransition :one
+SELECT * FROM charge_transitions
+WHERE charge_id = $1
+ORDER BY sequence DESC
+LIMIT 1;
+
+-- name: ListChargeTransitions :many
+SELECT * FROM charge_transitions
+WHERE charge_id = $1
+ORDER BY sequence;
+
+-- name: UpsertMirroredCustomer :exec
+INSERT INTO customers (
+    id, email, name, phone, description, metadata, created_at, synced_at, tenant_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+ON CONFLICT (id) DO UPDATE
+SET email = EXCLUDED.email,
+    name = EXCLUDED.name,
+    phone = EXCLUDED.phone,
+    description = EXCLUDED.description,
+    metadata = EXCLUDED.metadata,
+    synced_at = EXCLUDED.synced_at,
+    tenant_id = CASE WHEN EXCLUDED.tenant_id = 'default' THEN customers.tenant_id ELSE EXCLUDED.tenant_id END
+WHERE customers.synced_at <= EXCLUDED.synced_at
+  AND NOT EXISTS (SELECT 1 FROM customer_erasures WHERE customer_erasures.customer_id = customers.id);
+
+-- name: UpsertMirroredPaymentMethod :exec
+INSERT INTO payment_methods (
+    id, type, customer_id, card_last4, card_brand, card_exp_month, card_exp_year, card_fingerprint, metadata, created_at, synced_at, tenant_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+)
+ON CONFLICT (id) DO UPDATE
+SET type = EXCLUDED.type,
+    customer_id = EXCLUDED.customer_id,
+    card_last4 = EXCLUDED.card_last4,
+    card_brand = EXCLUDED.card_brand,
+    card_exp_month = EXCLUDED.card_exp_month,
+    card_exp_year = EXCLUDED.card_exp_year,
+    card_fingerprint = EXCLUDED.card_fingerprint,
+    metadata = EXCLUDED.metadata,
+    synced_at = EXCLUDED.synced_at,
+    tenant_id = CASE WHEN EXCLUDED.tenant_id = 'default' THEN payment_methods.tenant_id ELSE EXCLUDED.tenant_id END
+WHERE payment_methods.synced_at <= EXCLUDED.synced_at;
+
+-- name: DeleteMirroredPaymentMethod :exec
+DELETE FROM payment_methods
+WHERE id = $1 AND synced_at <= $2;
+
+-- name: DeleteCustomerPaymentMethods :exec
+DELETE FROM payment_methods
+WHERE customer_id = $1;
+
+-- name: UpsertMirroredCharge :exec
+INSERT INTO charges (
+    id, amount, amount_refunded, currency, status, captured, refunded, disputed,
+    customer_id, payment_method_id, invoice_id, description, metadata, created_at, synced_at, tenant_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16
+)
+ON CONFLICT (id) DO UPDATE
+SET amount = EXCLUDED.amount,
+    amount_refunded = EXCLUDED.amount_refunded,
+    currency = EXCLUDED.currency,
+    status = EXCLUDED.status,
+    captured = EXCLUDED.captured,
+    refunded = EXCLUDED.refunded,
+    disputed = EXCLUDED.disputed,
+    customer_id = EXCLUDED.customer_id,
+    payment_method_id = EXCLUDED.payment_method_id,
+    invoice_id = EXCLUDED.invoice_id,
+    description = EXCLUDED.description,
+    metadata = EXCLUDED.metadata,
+    synced_at = EXCLUDED.synced_at,
+    tenant_id = CASE WHEN EXCLUDED.tenant_id = 'default' THEN charges.tenant_id ELSE EXCLUDED.tenant_id END
+WHERE charges.synced_at <= EXCLUDED.synced_at;
+
+-- name: UpsertMirroredRefund :exec
+INSERT INTO refunds (
+    id, charge_id, amount, currency, status, reason, metadata, created_at, synced_at, tenant_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+)
+ON CONFLICT (id) DO UPDATE
+SET charge_id = EXCLUDED.charge_id,
+    amount = EXCLUDED.amount,
+    currency = EXCLUDED.currency,
+    status = EXCLUDED.status,
+    reason = EXCLUDED.reason,
+    metadata = EXCLUDED.metadata,
+    synced_at = EXCLUDED.synced_at,
+    tenant_id = CASE WHEN EXCLUDED.tenant_id = 'default' THEN refunds.tenant_id ELSE EXCLUDED.tenant_id END
+WHERE refunds.synced_at <= EXCLUDED.synced_at;
+
+-- name: UpsertSubscriptionPlan :exec
+INSERT INTO subscription_plans (
+    id, product_id, nickname, amount, currency, billing_interval, interval_count,
+    active, metadata, plan_created_at, synced_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+)
+ON CONFLICT (id) DO UPDATE
+SET product_id = EXCLUDED.product_id,
+    nickname = EXCLUDED.nickname,
+    amount = EXCLUDED.amount,
+    currency = EXCLUDED.currency,
+    billing_interval = EXCLUDED.billing_interval,
+    interval_count = EXCLUDED.interval_count,
+    active = EXCLUDED.active,
+    metadata = EXCLUDED.metadata,
+    synced_at = EXCLUDED.synced_at
+WHERE subscription_plans.synced_at <= EXCLUDED.synced_at;
+
+-- name: GetSubscriptionPlan :one
+SELECT * FROM subscription_plans
+WHERE id = $1;
+
+-- name: ListSubscriptionPlans :many
+SELECT * FROM subscription_plans
+WHERE ($1 = '' OR product_id = $1)
+ORDER BY plan_created_at DESC;
+
+-- name: UpsertSubscription :exec
+INSERT INTO subscriptions (
+    id, customer_id, plan_id, status, paused, cancel_at_period_end,
+    current_period_start, current_period_end, collection_method, days_until_due,
+    metadata, subscription_created_at, synced_at, tenant_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
+)
+ON CONFLICT (id) DO UPDATE
+SET customer_id = EXCLUDED.customer_id,
+    plan_id = EXCLUDED.plan_id,
+    status = EXCLUDED.status,
+    paused = EXCLUDED.paused,
+    cancel_at_period_end = EXCLUDED.cancel_at_period_end,
+    current_period_start = EXCLUDED.current_period_start,
+    current_period_end = EXCLUDED.current_period_end,
+    collection_method = EXCLUDED.collection_method,
+    days_until_due = EXCLUDED.days_until_due,
+    metadata = EXCLUDED.metadata,
+    synced_at = EXCLUDED.synced_at,
+    tenant_id = CASE WHEN EXCLUDED.tenant_id = 'default' THEN subscriptions.tenant_id ELSE EXCLUDED.tenant_id END
+WHERE subscriptions.synced_at <= EXCLUDED.synced_at;
+
+-- name: GetSubscription :one
+SELECT * FROM subscriptions
+WHERE id = $1 AND ($2 = '' OR tenant_id = $2);
+
+-- name: ListSubscriptions :many
+SELECT * FROM subscriptions
+WHERE ($1 = '' OR customer_id = $1) AND ($2 = '' OR status = $2)
+ORDER BY subscription_created_at DESC
+LIMIT $3;
+
+-- name: CreateQuarantine :one
+INSERT INTO quarantines (
+    id, subject_type, subject_id, reason, created_by
+) VALUES (
+    $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: GetQuarantine :one
+SELECT * FROM quarantines
+WHERE id = $1;
+
+-- name: GetActiveQuarantine :one
+SELECT * FROM quarantines
+WHERE subject_type = $1 AND subject_id = $2 AND lifted_at IS NULL;
+
+-- name: ListActiveQuarantines :many
+SELECT * FROM quarantines
+WHERE lifted_at IS NULL
+ORDER BY created_at DESC;
+
+-- name: LiftQuarantine :one
+UPDATE quarantines
+SET lifted_by = $2, lifted_at = NOW()
+WHERE id = $1 AND lifted_at IS NULL
+RETURNING *;
+
+-- name: RecordQuarantineActivity :exec
+INSERT INTO quarantine_activity (
+    id, quarantine_id, tenant_id, api_key_id, method, path, held_mutation_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+);
+
+-- name: ListQuarantineActivity :many
+SELECT * FROM quarantine_activity
+WHERE quarantine_id = $1
+ORDER BY created_at DESC
+LIMIT $2;
+
+-- name: CreateHeldMutation :one
+INSERT INTO held_mutations (
+    id, quarantine_id, tenant_id, api_key_id, operator_id, method, path, content_type, body, status
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+) RETURNING *;
+
+-- name: GetHeldMutation :one
+SELECT * FROM held_mutations
+WHERE id = $1;
+
+-- name: ListHeldMutations :many
+SELECT * FROM held_mutations
+WHERE $1 = '' OR status = $1
+ORDER BY created_at ASC;
+
+-- name: DecideHeldMutation :one
+UPDATE held_mutations
+SET status = $2, decided_by = $3, decided_at = NOW()
+WHERE id = $1 AND status = 'pending'
+RETURNING *;
+
+-- name: RecordHeldMutationResult :one
+UPDATE held_mutations
+SET status = $2, response_status = $3, response_body = $4
+WHERE id = $1
+RETURNING *;
+
+-- name: GetCustomFieldDefinition :one
+SELECT * FROM custom_field_definitions
+WHERE tenant_id = $1 LIMIT 1;
+
+-- name: UpsertCustomFieldDefinition :one
+INSERT INTO custom_field_definitions (
+    tenant_id, fields
+) VALUES (
+    $1, $2
+)
+ON CONFLICT (tenant_id) DO UPDATE
+SET fields = EXCLUDED.fields
+RETURNING *;
+
+-- name: DeleteCustomFieldDefinition :execrows
+DELETE FROM custom_field_definitions
+WHERE tenant_id = $1;
+
+-- name: CreateCompositeCharge :one
+INSERT INTO composite_charges (
+    id, tenant_id, amount, currency, refund_strategy
+) VALUES (
+    $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: GetCompositeCharge :one
+SELECT * FROM composite_charges
+WHERE id = $1 AND ($2 = '' OR tenant_id = $2);
+
+-- name: CreateCompositeChargeLeg :one
+INSERT INTO composite_charge_legs (
+    composite_charge_id, charge_id, position, priority, amount
+) VALUES (
+    $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: ListCompositeChargeLegs :many
+SELECT * FROM composite_charge_legs
+WHERE composite_charge_id = $1
+ORDER BY position;
+
+-- name: CreateCompositeRefund :one
+INSERT INTO composite_refunds (
+    id, composite_charge_id, tenant_id, amount, currency, reason, status
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+) RETURNING *;
+
+-- name: GetCompositeRefund :one
+SELECT * FROM composite_refunds
+WHERE id = $1 AND ($2 = '' OR tenant_id = $2);
+
+-- name: ListCompositeRefunds :many
+SELECT * FROM composite_refunds
+WHERE composite_charge_id = $1 AND ($2 = '' OR tenant_id = $2)
+ORDER BY created_at;
+
+-- name: UpdateCompositeRefundStatus :exec
+UPDATE composite_refunds
+SET status = $2
+WHERE id = $1;
+
+-- name: CreateCompositeRefundLeg :one
+INSERT INTO composite_refund_legs (
+    composite_refund_id, charge_id, position, amount, status
+) VALUES (
+    $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: ListCompositeRefundLegs :many
+SELECT * FROM composite_refund_legs
+WHERE composite_refund_id = $1
+ORDER BY position;
+
+-- name: UpdateCompositeRefundLeg :one
+UPDATE composite_refund_legs
+SET refund_id = $3, approval_id = $4, status = $5, failure_reason = $6
+WHERE composite_refund_id = $1 AND charge_id = $2
+RETURNING *;
+
+-- name: GetProviderCredential :one
+SELECT * FROM provider_credentials
+WHERE tenant_id = $1 AND provider = $2 LIMIT 1;
+
+-- name: ListProviderCredentials :many
+SELECT * FROM provider_credentials
+WHERE tenant_id = $1
+ORDER BY provider;
+
+-- name: UpsertProviderCredential :one
+INSERT INTO provider_credentials (
+    tenant_id, provider, ciphertext, masked_fields, version, verified_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+ON CONFLICT (tenant_id, provider) DO UPDATE
+SET ciphertext = EXCLUDED.ciphertext,
+    masked_fields = EXCLUDED.masked_fields,
+    version = EXCLUDED.version,
+    verified_at = EXCLUDED.verified_at
+RETURNING *;
+
+-- name: DeleteProviderCredential :execrows
+DELETE FROM provider_credentials
+WHERE tenant_id = $1 AND provider = $2;
+
+-- name: CreateProviderCredentialAudit :exec
+INSERT INTO provider_credential_audit (
+    id, tenant_id, provider, action, version, changed_fields, api_key_id, operator_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+);
+
+-- name: ListProviderCredentialAudit :many
+SELECT * FROM provider_credential_audit
+WHERE tenant_id = $1 AND provider = $2
+ORDER BY created_at DESC
+LIMIT $3;
+
+-- name: CreateDeadLetter :one
+INSERT INTO dlq_events (
+    id, source, dedupe_key, payload, headers, last_error, next_attempt_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+ON CONFLICT (source, dedupe_key) DO UPDATE
+SET last_error = EXCLUDED.last_error
+RETURNING *;
+
+-- name: GetDeadLetter :one
+SELECT * FROM dlq_events
+WHERE id = $1 LIMIT 1;
+
+-- name: ListDeadLetters :many
+SELECT * FROM dlq_events
+WHERE ($1 = '' OR status = $1) AND ($2 = '' OR source = $2)
+ORDER BY created_at DESC
+LIMIT $3;
+
+-- name: ClaimDueDeadLetters :many
+UPDATE dlq_events
+SET next_attempt_at = $1
+WHERE id IN (
+    SELECT id FROM dlq_events
+    WHERE status = 'pending' AND next_attempt_at <= NOW()
+    ORDER BY next_attempt_at
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: UpdateDeadLetter :one
+UPDATE dlq_events
+SET status = $2, attempts = $3, last_error = $4, next_attempt_at = $5, resolved_at = $6
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteDeadLetter :execrows
+DELETE FROM dlq_events
+WHERE id = $1;
+
+-- name: PurgeDeadLetters :execrows
+DELETE FROM dlq_events
+WHERE status = $1 AND created_at < $2;
+
+-- name: CreateCustomerReference :one
+INSERT INTO customer_references (
+    tenant_id, external_reference, customer_id
+) VALUES (
+    $1, $2, $3
+)
+ON CONFLICT (tenant_id, external_reference) DO NOTHING
+RETURNING *;
+
+-- name: GetCustomerReference :one
+SELECT * FROM customer_references
+WHERE tenant_id = $1 AND external_reference = $2 LIMIT 1;
+
+-- name: DeleteCustomerReferences :exec
+DELETE FROM customer_references
+WHERE customer_id = $1;
+
+-- name: GetCustomerChargeStats :many
+SELECT
+    currency,
+    COUNT(*) AS charge_count,
+    COUNT(*) FILTER (WHERE status = 'succeeded') AS succeeded_count,
+    COALESCE(SUM(amount) FILTER (WHERE status = 'succeeded'), 0)::bigint AS succeeded_amount,
+    COALESCE(SUM(amount_refunded) FILTER (WHERE status = 'succeeded'), 0)::bigint AS refunded_amount,
+    COUNT(*) FILTER (WHERE status = 'succeeded' AND amount_refunded > 0) AS refunded_count,
+    COUNT(*) FILTER (WHERE disputed) AS dispute_count,
+    MIN(created_at)::timestamptz AS first_charge_at,
+    MAX(created_at)::timestamptz AS last_charge_at
+FROM charges
+WHERE customer_id = sqlc.arg(customer_id) AND created_at >= sqlc.arg(created_from)
+GROUP BY currency
+ORDER BY currency;
+
+-- name: GetChargeCustomer :one
+SELECT customer_id FROM charges
+WHERE id = $1;
+
+-- name: GetChargeTenant :one
+SELECT tenant_id FROM charges
+WHERE id = $1;
+
+-- name: MarkMirroredChargeDisputed :exec
+UPDATE charges
+SET disputed = TRUE
+WHERE id = $1;
+
+-- name: CreateWebhookSecretRotation :one
+INSERT INTO webhook_secret_rotations (
+    id, old_endpoint_id, new_endpoint_id, new_secret, grace_ends_at
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: GetWebhookSecretRotation :one
+SELECT * FROM webhook_secret_rotations
+WHERE id = $1 LIMIT 1;
+
+-- name: GetPendingWebhookSecretRotation :one
+SELECT * FROM webhook_secret_rotations
+WHERE status = 'pending' LIMIT 1;
+
+-- name: GetLatestCompletedWebhookSecretRotation :one
+SELECT * FROM webhook_secret_rotations
+WHERE status = 'completed'
+ORDER BY completed_at DESC
+LIMIT 1;
+
+-- name: ListWebhookSecretRotations :many
+SELECT * FROM webhook_secret_rotations
+ORDER BY created_at DESC
+LIMIT $1;
+
+-- name: RecordWebhookSecretRotationDelivery :one
+UPDATE webhook_secret_rotations
+SET deliveries = deliveries + 1, confirmed_at = COALESCE(confirmed_at, NOW())
+WHERE id = $1 AND status = 'pending'
+RETURNING *;
+
+-- name: FinishWebhookSecretRotation :one
+UPDATE webhook_secret_rotations
+SET status = sqlc.arg(status), completed_at = sqlc.narg(completed_at), cancelled_at = sqlc.narg(cancelled_at)
+WHERE id = sqlc.arg(id) AND status = 'pending'
+RETURNING *;
+
+-- name: CreateOffboardingExport :one
+INSERT INTO offboarding_exports (
+    id, tenant_id, recipient_public_key
+) VALUES (
+    $1, $2, $3
+)
+RETURNING *;
+
+-- name: GetOffboardingExport :one
+SELECT * FROM offboarding_exports
+WHERE id = $1 LIMIT 1;
+
+-- name: ListOffboardingExports :many
+SELECT * FROM offboarding_exports
+WHERE tenant_id = $1
+ORDER BY created_at DESC
+LIMIT $2;
+
+-- name: ListRunnableOffboardingExports :many
+SELECT * FROM offboarding_exports
+WHERE status = 'pending' OR (status = 'running' AND started_at < NOW() - INTERVAL '1 hour')
+ORDER BY created_at
+LIMIT $1;
+
+-- name: ClaimOffboardingExport :one
+UPDATE offboarding_exports
+SET status = 'running', started_at = NOW()
+WHERE id = $1 AND (status = 'pending' OR (status = 'running' AND started_at < NOW() - INTERVAL '1 hour'))
+RETURNING *;
+
+-- name: StoreOffboardingArchive :exec
+INSERT INTO offboarding_archives (
+    export_id, archive
+) VALUES (
+    $1, $2
+)
+ON CONFLICT (export_id) DO UPDATE SET archive = EXCLUDED.archive, created_at = NOW();
+
+-- name: GetOffboardingArchive :one
+SELECT archive FROM offboarding_archives
+WHERE export_id = $1;
+
+-- name: CompleteOffboardingExport :one
+UPDATE offboarding_exports
+SET status = 'completed', manifest = sqlc.arg(manifest), archive_sha256 = sqlc.arg(archive_sha256), archive_size = sqlc.arg(archive_size), completed_at = NOW()
+WHERE id = sqlc.arg(id) AND status = 'running'
+RETURNING *;
+
+-- name: FailOffboardingExport :one
+UPDATE offboarding_exports
+SET status = 'failed', error = $2
+WHERE id = $1 AND status = 'running'
+RETURNING *;
+
+-- name: RecordOffboardingPANMigration :one
+UPDATE offboarding_exports
+SET pan_migration_status = $2, pan_migration_destination = $3, pan_migration_reference = $4, pan_migration_requested_at = NOW()
+WHERE id = $1 AND status = 'completed'
+RETURNING *;
+
+-- name: ListTenantCustomerIDs :many
+SELECT customer_id FROM customer_identities
+WHERE tenant_id = $1
+ORDER BY created_at;
+
+-- name: UpsertInvoice :exec
+INSERT INTO invoices (
+    id, customer_id, subscription_id, number, status, collection_method,
+    description, amount_due, amount_paid, amount_remaining, currency, due_date,
+    paid_out_of_band, hosted_invoice_url, invoice_pdf, custom_fields, metadata,
+    invoiced_at, synced_at, customer_name, customer_email, subtotal, tax, total,
+    lines, tax_amounts, tenant_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19,
+    $20, $21, $22, $23, $24, $25, $26,
+    COALESCE(NULLIF($27, ''), (SELECT tenant_id FROM customers WHERE customers.id = $2), 'default')
+)
+ON CONFLICT (id) DO UPDATE
+SET customer_id = EXCLUDED.customer_id,
+    subscription_id = EXCLUDED.subscription_id,
+    number = EXCLUDED.number,
+    status = EXCLUDED.status,
+    collection_method = EXCLUDED.collection_method,
+    description = EXCLUDED.description,
+    amount_due = EXCLUDED.amount_due,
+    amount_paid = EXCLUDED.amount_paid,
+    amount_remaining = EXCLUDED.amount_remaining,
+    currency = EXCLUDED.currency,
+    due_date = EXCLUDED.due_date,
+    paid_out_of_band = EXCLUDED.paid_out_of_band,
+    hosted_invoice_url = EXCLUDED.hosted_invoice_url,
+    invoice_pdf = EXCLUDED.invoice_pdf,
+    custom_fields = EXCLUDED.custom_fields,
+    metadata = EXCLUDED.metadata,
+    synced_at = EXCLUDED.synced_at,
+    customer_name = EXCLUDED.customer_name,
+    customer_email = EXCLUDED.customer_email,
+    subtotal = EXCLUDED.subtotal,
+    tax = EXCLUDED.tax,
+    total = EXCLUDED.total,
+    lines = EXCLUDED.lines,
+    tax_amounts = EXCLUDED.tax_amounts
+WHERE invoices.synced_at <= EXCLUDED.synced_at;
+
+-- name: GetInvoice :one
+SELECT * FROM invoices
+WHERE id = $1 AND ($2 = '' OR tenant_id = $2);
+
+-- name: ListInvoices :many
+SELECT * FROM invoices
+WHERE ($1 = '' OR customer_id = $1) AND ($2 = '' OR status = $2) AND ($4 = '' OR tenant_id = $4)
+ORDER BY invoiced_at DESC
+LIMIT $3;
+
+-- name: DeleteInvoice :exec
+DELETE FROM invoices
+WHERE id = $1;
+
+-- name: CreatePaymentLink :one
+INSERT INTO payment_links (
+    id, url, amount, currency, product_name, price_id, expires_at, max_uses, metadata, tenant_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+)
+RETURNING *;
+
+-- name: GetPaymentLink :one
+SELECT * FROM payment_links
+WHERE id = $1 AND ($2 = '' OR tenant_id = $2);
+
+-- name: ListPaymentLinks :many
+SELECT * FROM payment_links
+WHERE ($1 = FALSE OR active = $1) AND ($3 = '' OR tenant_id = $3)
+ORDER BY created_at DESC
+LIMIT $2;
+
+-- name: DeactivatePaymentLink :one
+UPDATE payment_links
+SET active = FALSE, deactivated_at = $2
+WHERE id = $1 AND active AND ($3 = '' OR tenant_id = $3)
+RETURNING *;
+
+-- name: ListExpiredPaymentLinks :many
+SELECT * FROM payment_links
+WHERE active AND expires_at IS NOT NULL AND expires_at <= $1
+ORDER BY expires_at;
+
+-- name: InsertPaymentLinkConversion :execrows
+INSERT INTO payment_link_conversions (
+    session_id, payment_link_id, amount_total, currency, customer_id, payment_intent_id, completed_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+ON CONFLICT (session_id) DO NOTHING;
+
+-- name: AddPaymentLinkConversion :one
+UPDATE payment_links
+SET conversions = conversions + 1, amount_collected = $2 + amount_collected
+WHERE id = $1
+RETURNING *;
+
+-- name: ListPaymentLinkConversions :many
+SELECT * FROM payment_link_conversions
+WHERE payment_link_id = $1
+ORDER BY completed_at DESC
+LIMIT $2;
+
+-- name: CreateReconciliationRun :one
+INSERT INTO reconciliation_runs (
+    id, status, period_start, period_end, started_at
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: CompleteReconciliationRun :one
+UPDATE reconciliation_runs
+SET status = sqlc.arg(status),
+    transactions_checked = sqlc.arg(transactions_checked),
+    payouts_checked = sqlc.arg(payouts_checked),
+    matched = sqlc.arg(matched),
+    mismatches = sqlc.arg(mismatches),
+    error = sqlc.arg(error),
+    completed_at = sqlc.arg(completed_at)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: GetReconciliationRun :one
+SELECT * FROM reconciliation_runs
+WHERE id = $1 LIMIT 1;
+
+-- name: ListReconciliationRuns :many
+SELECT * FROM reconciliation_runs
+ORDER BY started_at DESC
+LIMIT $1;
+
+-- name: CreateReconciliationMismatch :exec
+INSERT INTO reconciliation_mismatches (
+    id, run_id, kind, object_type, object_id, balance_transaction_id, provider_amount, local_amount, currency, detail
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+);
+
+-- name: ListReconciliationMismatches :many
+SELECT * FROM reconciliation_mismatches
+WHERE run_id = $1
+ORDER BY kind, object_id;
+
+-- name: ListCapturedChargesCreatedBetween :many
+SELECT * FROM charges
+WHERE captured = TRUE AND created_at >= sqlc.arg(created_from) AND created_at < sqlc.arg(created_to)
+ORDER BY created_at;
+
+-- name: ListSucceededRefundsCreatedBetween :many
+SELECT * FROM refunds
+WHERE status = 'succeeded' AND created_at >= sqlc.arg(created_from) AND created_at < sqlc.arg(created_to)
+ORDER BY created_at;
+
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (
+    id, name, secret_hash, secret_hint, scopes, tenant_id, created_by, rotated_from
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING *;
+
+-- name: GetAPIKey :one
+SELECT * FROM api_keys
+WHERE id = $1 LIMIT 1;
+
+-- name: GetAPIKeyBySecretHash :one
+SELECT * FROM api_keys
+WHERE secret_hash = $1;
+
+-- name: ListAPIKeys :many
+SELECT * FROM api_keys
+ORDER BY created_at DESC;
+
+-- name: ExpireAPIKey :execrows
+UPDATE api_keys
+SET expires_at = $2
+WHERE id = $1 AND revoked_at IS NULL;
+
+-- name: RevokeAPIKey :execrows
+UPDATE api_keys
+SET revoked_at = $2
+WHERE id = $1 AND revoked_at IS NULL;
+
+-- name: GetTenantConfig :one
+SELECT * FROM tenant_configs
+WHERE tenant_id = $1;
+
+-- name: ListTenantConfigs :many
+SELECT * FROM tenant_configs
+ORDER BY tenant_id;
+
+-- name: UpsertTenantConfig :one
+INSERT INTO tenant_configs (
+    tenant_id, name, status, default_provider
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (tenant_id) DO UPDATE
+SET name = EXCLUDED.name,
+    status = EXCLUDED.status,
+    default_provider = EXCLUDED.default_provider
+RETURNING *;
+
+-- name: CreateSmartRoutingRule :one
+INSERT INTO smart_routing_rules (
+    id, currency, bin_country, min_amount, max_amount, strategy,
+    providers, pinned_provider, priority, enabled, description, updated_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+)
+RETURNING *;
+
+-- name: GetSmartRoutingRule :one
+SELECT * FROM smart_routing_rules
+WHERE id = $1;
+
+-- name: ListSmartRoutingRules :many
+SELECT * FROM smart_routing_rules
+ORDER BY priority, created_at;
+
+-- name: UpdateSmartRoutingRule :one
+UPDATE smart_routing_rules
+SET currency = $2,
+    bin_country = $3,
+    min_amount = $4,
+    max_amount = $5,
+    strategy = $6,
+    providers = $7,
+    pinned_provider = $8,
+    priority = $9,
+    enabled = $10,
+    description = $11,
+    updated_by = $12
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteSmartRoutingRule :execrows
+DELETE FROM smart_routing_rules
+WHERE id = $1;
+
+-- name: RecordFraudScreening :exec
+INSERT INTO fraud_screenings (
+    id, tenant_id, customer_id, card_fingerprint, ip_address,
+    amount, currency, decision, findings
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+);
+
+-- name: CountRecentFraudScreenings :one
+SELECT
+    COUNT(*) FILTER (WHERE sqlc.arg(customer_id)::text <> '' AND customer_id = sqlc.arg(customer_id)) AS customer_count,
+    COUNT(*) FILTER (WHERE sqlc.arg(card_fingerprint)::text <> '' AND card_fingerprint = sqlc.arg(card_fingerprint)) AS card_count,
+    COUNT(*) FILTER (WHERE sqlc.arg(ip_address)::text <> '' AND ip_address = sqlc.arg(ip_address)) AS ip_count
+FROM fraud_screenings
+WHERE created_at >= sqlc.arg(since);
+
+-- name: GetCustomerScreenedAmounts :one
+SELECT COUNT(*) AS charge_count, COALESCE(AVG(amount), 0)::bigint AS average_amount
+FROM fraud_screenings
+WHERE tenant_id = $1 AND customer_id = $2 AND currency = $3
+  AND decision = 'accept' AND created_at >= $4;
+
+-- name: CreateFraudListEntry :one
+INSERT INTO fraud_list_entries (
+    id, list, entry_type, value, reason, created_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+RETURNING *;
+
+-- name: GetFraudListEntry :one
+SELECT * FROM fraud_list_entries
+WHERE id = $1;
+
+-- name: ListFraudListEntries :many
+SELECT * FROM fraud_list_entries
+WHERE ($1 = '' OR list = $1)
+ORDER BY created_at DESC;
+
+-- name: MatchFraudListEntries :many
+SELECT * FROM fraud_list_entries
+WHERE (entry_type = 'customer' AND value = sqlc.arg(customer_id)::text)
+   OR (entry_type = 'card_fingerprint' AND value = sqlc.arg(card_fingerprint)::text)
+   OR (entry_type = 'ip_address' AND value = sqlc.arg(ip_address)::text)
+ORDER BY list, created_at;
+
+-- name: DeleteFraudListEntry :execrows
+DELETE FROM fraud_list_entries
+WHERE id = $1;
+
+-- name: RecordRadarScore :exec
+INSERT INTO fraud_radar_scores (
+    charge_id, customer_id, card_fingerprint, risk_score, risk_level
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (charge_id) DO UPDATE
+SET risk_score = EXCLUDED.risk_score,
+    risk_level = EXCLUDED.risk_level;
+
+-- name: GetLatestRadarScore :one
+SELECT * FROM fraud_radar_scores
+WHERE ((sqlc.arg(customer_id)::text <> '' AND customer_id = sqlc.arg(customer_id))
+    OR (sqlc.arg(card_fingerprint)::text <> '' AND card_fingerprint = sqlc.arg(card_fingerprint)))
+  AND created_at >= sqlc.arg(since)
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: CreateFraudReview :one
+INSERT INTO fraud_reviews (
+    id, tenant_id, screening_id, customer_id, amount, currency, request, findings
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING *;
+
+-- name: GetFraudReview :one
+SELECT * FROM fraud_reviews
+WHERE id = $1 AND ($2 = '' OR tenant_id = $2);
+
+-- name: ListFraudReviews :many
+SELECT * FROM fraud_reviews
+WHERE ($1 = '' OR tenant_id = $1)
+  AND ($2 = '' OR status = $2)
+ORDER BY created_at DESC
+LIMIT $3;
+
+-- name: DecideFraudReview :one
+UPDATE fraud_reviews
+SET status = $2,
+    decided_by = $3,
+    decided_at = NOW()
+WHERE id = $1 AND status = 'pending'
+RETURNING *;
+
+-- name: RecordFraudReviewCharge :one
+UPDATE fraud_reviews
+SET status = $2,
+    charge_id = $3,
+    failure_reason = $4
+WHERE id = $1
+RETURNING *;
+
+-- name: GetDisputeEvidence :one
+SELECT * FROM dispute_evidence
+WHERE dispute_id = $1;
+
+-- name: SaveDisputeEvidence :one
+INSERT INTO dispute_evidence (
+    dispute_id, fields, updated_by
+) VALUES (
+    $1, $2, $3
+)
+ON CONFLICT (dispute_id) DO UPDATE
+SET fields = EXCLUDED.fields,
+    updated_by = EXCLUDED.updated_by
+RETURNING *;
+
+-- name: MarkDisputeEvidenceSubmitted :one
+UPDATE dispute_evidence
+SET submitted_by = $2,
+    submitted_at = NOW()
+WHERE dispute_id = $1
+RETURNING *;
+
+-- name: CreateDisputeEvidenceFile :one
+INSERT INTO dispute_evidence_files (
+    id, dispute_id, provider_file_id, field, filename, content_type, size, uploaded_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING *;
+
+-- name: ListDisputeEvidenceFiles :many
+SELECT * FROM dispute_evidence_files
+WHERE dispute_id = $1
+ORDER BY created_at;
+
+-- name: ListDisputesDueForEvidence :many
+SELECT * FROM disputes
+WHERE status IN ('needs_response', 'warning_needs_response')
+  AND submission_count = 0
+  AND evidence_due_by > sqlc.arg(due_after)
+  AND evidence_due_by <= sqlc.arg(due_before)
+ORDER BY evidence_due_by;
+
+-- name: ClaimDisputeEvidenceReminder :execrows
+INSERT INTO dispute_evidence_reminders (
+    dispute_id, due_by
+) VALUES (
+    $1, $2
+)
+ON CONFLICT (dispute_id, due_by) DO NOTHING;
+
+-- name: InsertUsageRecord :execrows
+INSERT INTO usage_records (
+    id, subscription_id, subscription_item_id, price_id, quantity, action, recorded_at, idempotency_key
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+ON CONFLICT DO NOTHING;
+
+-- name: GetUsageRecord :one
+SELECT * FROM usage_records
+WHERE id = $1;
+
+-- name: GetUsageRecordByKey :one
+SELECT * FROM usage_records
+WHERE subscription_id = $1 AND idempotency_key = $2;
+
+-- name: ListUsageRecords :many
+SELECT * FROM usage_records
+WHERE subscription_id = sqlc.arg(subscription_id) AND recorded_at >= sqlc.arg(recorded_from) AND recorded_at < sqlc.arg(recorded_to)
+ORDER BY recorded_at, created_at;
+
+-- name: ListUnreportedUsageRecords :many
+SELECT * FROM usage_records
+WHERE reported_at IS NULL AND attempts < sqlc.arg(max_attempts)
+ORDER BY created_at
+LIMIT sqlc.arg(batch_size);
+
+-- name: MarkUsageRecordReported :one
+UPDATE usage_records
+SET reported_at = $2, provider_record_id = $3, attempts = attempts + 1, last_error = ''
+WHERE id = $1
+RETURNING *;
+
+-- name: RecordUsageReportFailure :exec
+UPDATE usage_records
+SET attempts = attempts + 1, last_error = $2
+WHERE id = $1;
+
+-- name: OpenDunningCase :execrows
+INSERT INTO dunning_cases (
+    id, invoice_id, subscription_id, customer_id, amount_due, currency, state, next_retry_at, last_error, failed_at, tenant_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10,
+    COALESCE(NULLIF($11, ''), (SELECT tenant_id FROM customers WHERE customers.id = $4), 'default')
+)
+ON CONFLICT DO NOTHING;
+
+-- name: GetDunningCase :one
+SELECT * FROM dunning_cases
+WHERE id = $1 AND ($2 = '' OR tenant_id = $2);
+
+-- name: GetDunningCaseByInvoice :one
+SELECT * FROM dunning_cases
+WHERE invoice_id = $1;
+
+-- name: ListDunningCases :many
+SELECT * FROM dunning_cases
+WHERE ($1 = '' OR state = $1) AND ($2 = '' OR customer_id = $2) AND ($3 = '' OR subscription_id = $3) AND ($5 = '' OR tenant_id = $5)
+ORDER BY created_at DESC
+LIMIT $4;
+
+-- name: ListOpenDunningCases :many
+SELECT * FROM dunning_cases
+WHERE resolved_at IS NULL
+ORDER BY failed_at
+LIMIT $1;
+
+-- name: UpdateDunningCase :one
+UPDATE dunning_cases
+SET state = $2, attempts = $3, next_retry_at = $4, last_error = $5, resolved_at = $6
+WHERE id = $1 AND resolved_at IS NULL
+RETURNING *;
+
+-- name: TrackAuthorization :execrows
+INSERT INTO authorizations (
+    charge_id, tenant_id, provider, amount, currency, multi_capture, status, expires_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+ON CONFLICT DO NOTHING;
+
+-- name: GetAuthorization :one
+SELECT * FROM authorizations
+WHERE charge_id = $1 AND ($2 = '' OR tenant_id = $2);
+
+-- name: ListAuthorizations :many
+SELECT * FROM authorizations
+WHERE ($1 = '' OR tenant_id = $1) AND ($2 = '' OR status = $2)
+ORDER BY created_at DESC
+LIMIT $3;
+
+-- name: ListStaleAuthorizations :many
+SELECT * FROM authorizations
+WHERE status = 'open' AND expires_at < $1
+ORDER BY expires_at
+LIMIT $2;
+
+-- name: UpdateAuthorization :one
+UPDATE authorizations
+SET amount_captured = $2, captures = $3, status = $4, closed_at = $5
+WHERE charge_id = $1 AND status = 'open'
+RETURNING *;
+
+-- name: CreateRefundBatch :one
+INSERT INTO refund_batches (
+    id, tenant_id, api_key_id, operator_id, requests, scheduled_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+RETURNING *;
+
+-- name: GetRefundBatch :one
+SELECT * FROM refund_batches
+WHERE id = $1 AND ($2 = '' OR tenant_id = $2) LIMIT 1;
+
+-- name: ListRunnableRefundBatches :many
+SELECT * FROM refund_batches
+WHERE (status = 'pending' AND scheduled_at <= $1) OR (status = 'running' AND started_at < NOW() - INTERVAL '1 hour')
+ORDER BY scheduled_at
+LIMIT $2;
+
+-- name: ClaimRefundBatch :one
+UPDATE refund_batches
+SET status = 'running', started_at = NOW()
+WHERE id = $1 AND (status = 'pending' OR (status = 'running' AND started_at < NOW() - INTERVAL '1 hour'))
+RETURNING *;
+
+-- name: FinishRefundBatch :one
+UPDATE refund_batches
+SET status = sqlc.arg(status), completed_at = NOW()
+WHERE id = sqlc.arg(id) AND status = sqlc.arg(from_status)
+RETURNING *;
+
+-- name: RecordRefundBatchItem :exec
+INSERT INTO refund_batch_items (
+    batch_id, item_index, status, amount, refund_id, approval_id, error
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+ON CONFLICT (batch_id, item_index) DO UPDATE
+SET status = EXCLUDED.status, amount = EXCLUDED.amount, refund_id = EXCLUDED.refund_id, approval_id = EXCLUDED.approval_id, error = EXCLUDED.error;
+
+-- name: ListRefundBatchItems :many
+SELECT * FROM refund_batch_items
+WHERE batch_id = $1
+ORDER BY item_index;
+
+-- name: ListTenantCustomers :many
+SELECT * FROM customers
+WHERE tenant_id = sqlc.arg(tenant_id)
+  AND (sqlc.arg(email)::text = '' OR lower(email) = sqlc.arg(email)::text)
+  AND (sqlc.arg(name_prefix)::text = '' OR lower(name) LIKE sqlc.arg(name_prefix)::text || '%')
+  AND (sqlc.arg(metadata)::jsonb = '{}'::jsonb OR metadata @> sqlc.arg(metadata)::jsonb)
+  AND (sqlc.narg(created_after)::timestamptz IS NULL OR created_at >= sqlc.narg(created_after))
+  AND (sqlc.narg(created_before)::timestamptz IS NULL OR created_at < sqlc.narg(created_before))
+  AND (sqlc.arg(starting_after)::text = '' OR (created_at, id) < (
+      SELECT created_at, id FROM customers WHERE id = sqlc.arg(starting_after)::text
+  ))
+  AND NOT EXISTS (SELECT 1 FROM customer_deletions WHERE customer_deletions.customer_id = customers.id)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(limit);
+
+-- name: SearchTenantCustomers :many
+SELECT * FROM customers
+WHERE tenant_id = sqlc.arg(tenant_id)
+  AND (id = sqlc.arg(query)::text
+    OR lower(email) LIKE sqlc.arg(prefix)::text || '%'
+    OR lower(name) LIKE sqlc.arg(prefix)::text || '%')
+  AND NOT EXISTS (SELECT 1 FROM customer_deletions WHERE customer_deletions.customer_id = customers.id)
+ORDER BY (id = sqlc.arg(query)::text) DESC, name, id
+LIMIT sqlc.arg(limit);
+
+-- name: SearchTenantCharges :many
+SELECT * FROM charges
+WHERE tenant_id = sqlc.arg(tenant_id)
+  AND (sqlc.arg(status)::text = '' OR status = sqlc.arg(status)::text)
+  AND (sqlc.arg(currency)::text = '' OR currency = sqlc.arg(currency)::text)
+  AND (sqlc.arg(customer_id)::text = '' OR customer_id = sqlc.arg(customer_id)::text)
+  AND (sqlc.narg(min_amount)::bigint IS NULL OR amount >= sqlc.narg(min_amount))
+  AND (sqlc.narg(max_amount)::bigint IS NULL OR amount <= sqlc.narg(max_amount))
+  AND (sqlc.arg(card_brand)::text = '' AND sqlc.arg(card_last4)::text = '' OR EXISTS (
+      SELECT 1 FROM payment_methods
+      WHERE payment_methods.id = charges.payment_method_id
+        AND (sqlc.arg(card_brand)::text = '' OR lower(payment_methods.card_brand) = sqlc.arg(card_brand)::text)
+        AND (sqlc.arg(card_last4)::text = '' OR payment_methods.card_last4 = sqlc.arg(card_last4)::text)
+  ))
+  AND (sqlc.arg(metadata)::jsonb = '{}'::jsonb OR metadata @> sqlc.arg(metadata)::jsonb)
+  AND (sqlc.narg(created_after)::timestamptz IS NULL OR created_at >= sqlc.narg(created_after))
+  AND (sqlc.narg(created_before)::timestamptz IS NULL OR created_at < sqlc.narg(created_before))
+  AND (sqlc.arg(starting_after)::text = '' OR (created_at, id) < (
+      SELECT created_at, id FROM charges WHERE id = sqlc.arg(starting_after)::text
+  ))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(limit);
+
+-- name: CreateCustomerDeletion :one
+INSERT INTO customer_deletions (
+    customer_id, tenant_id, deleted_at, purge_after
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (customer_id) DO UPDATE
+SET customer_id = EXCLUDED.customer_id
+RETURNING *;
+
+-- name: GetCustomerDeletion :one
+SELECT * FROM customer_deletions
+WHERE customer_id = $1;
+
+-- name: DeleteCustomerDeletion :execrows
+DELETE FROM customer_deletions
+WHERE customer_id = $1 AND purged_at IS NULL;
+
+-- name: ListDueCustomerDeletions :many
+SELECT * FROM customer_deletions
+WHERE purge_after <= $1 AND purged_at IS NULL
+ORDER BY purge_after
+LIMIT $2;
+
+-- name: ClaimCustomerDeletion :one
+UPDATE customer_deletions
+SET purged_at = $2
+WHERE customer_id = $1 AND purged_at IS NULL
+RETURNING *;
+
+-- name: ReleaseCustomerDeletion :exec
+UPDATE customer_deletions
+SET purged_at = NULL
+WHERE customer_id = $1;
+
+-- name: RecordCustomerErasure :one
+INSERT INTO customer_erasures (
+    customer_id, tenant_id, erased_at
+) VALUES (
+    $1, $2, $3
+)
+ON CONFLICT (customer_id) DO UPDATE
+SET customer_id = EXCLUDED.customer_id
+RETURNING *;
+
+-- name: GetCustomerErasure :one
+SELECT * FROM customer_erasures
+WHERE customer_id = $1;
+
+-- name: AnonymizeCustomer :exec
+INSERT INTO customers (
+    id, email, name, tenant_id, synced_at
+) VALUES (
+    $1, '', '', $2, $3
+)
+ON CONFLICT (id) DO UPDATE
+SET email = '',
+    name = '',
+    phone = NULL,
+    description = NULL;
+
+-- name: AnonymizeChargeListRows :exec
+UPDATE charge_list_rows
+SET customer_email = '',
+    customer_name = ''
+WHERE customer_id = $1;
+
+-- name: CreateAuditEntry :execrows
+INSERT INTO audit_log (
+    sequence, tenant_id, actor_type, actor_id, operator_id, action, resource_type, resource_id,
+    method, path, status, before_state, after_state, changes, ip, request_id, created_at, previous_hash, hash
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19
+)
+ON CONFLICT (sequence) DO NOTHING;
+
+-- name: GetAuditEntry :one
+SELECT * FROM audit_log
+WHERE sequence = $1;
+
+-- name: GetLatestAuditEntry :one
+SELECT * FROM audit_log
+ORDER BY sequence DESC
+LIMIT 1;
+
+-- name: GetLatestResourceAuditEntry :one
+SELECT * FROM audit_log
+WHERE resource_type = $1 AND resource_id = $2
+ORDER BY sequence DESC
+LIMIT 1;
+
+-- name: ListAuditEntries :many
+SELECT * FROM audit_log
+WHERE (sqlc.arg(tenant_id)::text = '' OR tenant_id = sqlc.arg(tenant_id)::text)
+  AND (sqlc.arg(actor_id)::text = '' OR actor_id = sqlc.arg(actor_id)::text)
+  AND (sqlc.arg(action)::text = '' OR action = sqlc.arg(action)::text)
+  AND (sqlc.arg(resource_type)::text = '' OR resource_type = sqlc.arg(resource_type)::text)
+  AND (sqlc.arg(resource_id)::text = '' OR resource_id = sqlc.arg(resource_id)::text)
+  AND (sqlc.narg(created_after)::timestamptz IS NULL OR created_at >= sqlc.narg(created_after))
+  AND (sqlc.narg(created_before)::timestamptz IS NULL OR created_at < sqlc.narg(created_before))
+  AND (sqlc.arg(before_sequence)::bigint = 0 OR sequence < sqlc.arg(before_sequence)::bigint)
+ORDER BY sequence DESC
+LIMIT sqlc.arg(limit);
+
+-- name: ListAuditEntriesFrom :many
+SELECT * FROM audit_log
+WHERE sequence >= $1
+ORDER BY sequence
+LIMIT $2;
+
+-- name: CountFingerprintCustomers :one
+SELECT COUNT(DISTINCT customer_id) AS customer_count
+FROM payment_methods
+WHERE tenant_id = sqlc.arg(tenant_id)
+  AND card_fingerprint = sqlc.arg(card_fingerprint)
+  AND customer_id <> sqlc.arg(customer_id);
+
+-- name: CountFingerprintChargebacks :one
+SELECT COUNT(*) AS chargeback_count
+FROM disputes
+WHERE disputed_at >= sqlc.arg(since)::timestamptz
+  AND status NOT IN ('won', 'warning_closed')
+  AND EXISTS (
+      SELECT 1 FROM charges, payment_methods
+      WHERE charges.id = disputes.charge_id
+        AND payment_methods.id = charges.payment_method_id
+        AND charges.tenant_id = sqlc.arg(tenant_id)::text
+        AND payment_methods.card_fingerprint = sqlc.arg(card_fingerprint)::text
+  );
+
+-- name: CreateJobRun :one
+INSERT INTO job_runs (
+    id, job_name, status, triggered_by, attempts, instance, started_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING *;
+
+-- name: FinishJobRun :one
+UPDATE job_runs
+SET status = $2, attempts = $3, error = $4, finished_at = $5
+WHERE id = $1
+RETURNING *;
+
+-- name: AbandonJobRuns :exec
+UPDATE job_runs
+SET status = 'abandoned', finished_at = $2
+WHERE job_name = $1 AND status = 'running';
+
+-- name: ListJobRuns :many
+SELECT * FROM job_runs
+WHERE job_name = $1
+ORDER BY started_at DESC
+LIMIT $2;
+
+-- name: SaveSimulatorObject :exec
+INSERT INTO simulator_objects (
+    tenant_id, id, kind, parent_id, data
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (tenant_id, id) DO UPDATE
+SET parent_id = EXCLUDED.parent_id, data = EXCLUDED.data, updated_at = NOW();
+
+-- name: GetSimulatorObject :one
+SELECT * FROM simulator_objects
+WHERE tenant_id = $1 AND kind = $2 AND id = $3;
+
+-- name: ListSimulatorObjects :many
+SELECT * FROM simulator_objects
+WHERE tenant_id = sqlc.arg(tenant_id)
+  AND kind = sqlc.arg(kind)
+  AND (sqlc.arg(parent_id)::text = '' OR parent_id = sqlc.arg(parent_id)::text)
+  AND (sqlc.arg(cursor)::text = '' OR (created_at, id) < (
+      SELECT cursor_object.created_at, cursor_object.id FROM simulator_objects cursor_object
+      WHERE cursor_object.tenant_id = sqlc.arg(tenant_id) AND cursor_object.id = sqlc.arg(cursor)::text
+  ))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(limit);
+
+-- name: DeleteSimulatorObject :execrows
+DELETE FROM simulator_objects
+WHERE tenant_id = $1 AND kind = $2 AND id = $3;
+
+-- name: CreateWebhookEndpoint :one
+INSERT INTO webhook_endpoints (
+    id, tenant_id, url, description, event_types, secret_ciphertext, enabled
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING *;
+
+-- name: GetWebhookEndpoint :one
+SELECT * FROM webhook_endpoints
+WHERE tenant_id = $1 AND id = $2 LIMIT 1;
+
+-- name: ListWebhookEndpoints :many
+SELECT * FROM webhook_endpoints
+WHERE tenant_id = $1
+ORDER BY created_at;
+
+-- name: UpdateWebhookEndpoint :one
+UPDATE webhook_endpoints
+SET url = $3, description = $4, event_types = $5, enabled = $6
+WHERE tenant_id = $1 AND id = $2
+RETURNING *;
+
+-- name: DeleteWebhookEndpoint :execrows
+DELETE FROM webhook_endpoints
+WHERE tenant_id = $1 AND id = $2;
+
+-- name: CreateWebhookDelivery :one
+INSERT INTO webhook_deliveries (
+    id, tenant_id, endpoint_id, event_id, event_type, payload, next_attempt_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+ON CONFLICT (endpoint_id, event_id) DO UPDATE
+SET event_type = EXCLUDED.event_type
+RETURNING *;
+
+-- name: GetWebhookDelivery :one
+SELECT * FROM webhook_deliveries
+WHERE tenant_id = $1 AND id = $2 LIMIT 1;
+
+-- name: ListWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE tenant_id = $1 AND endpoint_id = $2 AND ($3 = '' OR status = $3)
+ORDER BY created_at DESC
+LIMIT $4;
+
+-- name: ClaimDueWebhookDeliveries :many
+UPDATE webhook_deliveries
+SET next_attempt_at = $1
+WHERE id IN (
+    SELECT id FROM webhook_deliveries
+    WHERE status = 'pending' AND next_attempt_at <= NOW()
+    ORDER BY next_attempt_at
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: UpdateWebhookDelivery :one
+UPDATE webhook_deliveries
+SET status = $2, attempts = $3, last_status_code = $4, last_error = $5, next_attempt_at = $6, delivered_at = $7
+WHERE id = $1
+RETURNING *;
+
+-- name: CreateWebhookDeliveryAttempt :one
+INSERT INTO webhook_delivery_attempts (
+    id, delivery_id, status_code, response_body, error, duration_ms, manual
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING *;
+
+-- name: ListWebhookDeliveryAttempts :many
+SELECT * FROM webhook_delivery_attempts
+WHERE delivery_id = $1
+ORDER BY attempted_at;
+
+-- name: ArchiveEvent :exec
+INSERT INTO event_archive (
+    id, tenant_id, type, subject, occurred_at, payload
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+ON CONFLICT (id) DO NOTHING;
+
+-- name: ListArchivedEvents :many
+SELECT * FROM event_archive
+WHERE occurred_at >= sqlc.arg(occurred_from)
+  AND occurred_at < sqlc.arg(occurred_to)
+  AND (sqlc.arg(tenant_id)::text = '' OR tenant_id = sqlc.arg(tenant_id)::text)
+  AND (sqlc.arg(type_pattern)::text = '' OR type LIKE sqlc.arg(type_pattern)::text)
+  AND (sqlc.arg(subject)::text = '' OR subject = sqlc.arg(subject)::text)
+  AND (occurred_at, id) > (sqlc.arg(after_time)::timestamptz, sqlc.arg(after_id)::text)
+ORDER BY occurred_at, id
+LIMIT sqlc.arg(limit);
+
+-- name: PurgeArchivedEvents :execrows
+DELETE FROM event_archive
+WHERE occurred_at < $1;
+
+-- name: InsertOutboxEvent :exec
+INSERT INTO event_outbox (
+    id, tenant_id, payload
+) VALUES (
+    $1, $2, $3
+)
+ON CONFLICT (id) DO NOTHING;
+
+-- name: ListOutboxEvents :many
+SELECT * FROM event_outbox
+ORDER BY created_at, id
+LIMIT $1;
+
+-- name: DeleteOutboxEvent :exec
+DELETE FROM event_outbox
+WHERE id = $1;
+
+-- name: GetReceiptSettings :one
+SELECT * FROM receipt_settings
+WHERE tenant_id = $1 LIMIT 1;
+
+-- name: UpsertReceiptSettings :one
+INSERT INTO receipt_settings (
+    tenant_id, send_receipt_emails, descriptor_suffix, business_name, support_email
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (tenant_id) DO UPDATE
+SET send_receipt_emails = EXCLUDED.send_receipt_emails,
+    descriptor_suffix = EXCLUDED.descriptor_suffix,
+    business_name = EXCLUDED.business_name,
+    support_email = EXCLUDED.support_email
+RETURNING *;
+
+-- name: GetDocumentTemplate :one
+SELECT * FROM document_templates
+WHERE tenant_id = $1 AND kind = $2 LIMIT 1;
+
+-- name: UpsertDocumentTemplate :one
+INSERT INTO document_templates (
+    tenant_id, kind, business_name, address, tax_id, accent_color, footer, language
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+ON CONFLICT (tenant_id, kind) DO UPDATE
+SET business_name = EXCLUDED.business_name,
+    address = EXCLUDED.address,
+    tax_id = EXCLUDED.tax_id,
+    accent_color = EXCLUDED.accent_color,
+    footer = EXCLUDED.footer,
+    language = EXCLUDED.language
+RETURNING *;
+
+-- name: DeleteDocumentTemplate :execrows
+DELETE FROM document_templates
+WHERE tenant_id = $1 AND kind = $2;
+
+-- name: GetDocument :one
+SELECT * FROM documents
+WHERE id = $1 AND tenant_id = $2 LIMIT 1;
+
+-- name: GetDocumentBySource :one
+SELECT * FROM documents
+WHERE tenant_id = $1 AND kind = $2 AND source_id = $3 AND language = $4 LIMIT 1;
+
+-- name: UpsertDocument :one
+INSERT INTO documents (
+    id, tenant_id, kind, source_id, language, object_key, checksum, size_bytes
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+ON CONFLICT (tenant_id, kind, source_id, language) DO UPDATE
+SET object_key = EXCLUDED.object_key,
+    checksum = EXCLUDED.checksum,
+    size_bytes = EXCLUDED.size_bytes
+RETURNING *;
+
+-- name: GetPaymentMethodPreference :one
+SELECT * FROM payment_method_preferences
+WHERE tenant_id = $1 AND customer_id = $2 LIMIT 1;
+
+-- name: UpsertPaymentMethodPreference :one
+INSERT INTO payment_method_preferences (
+    tenant_id, customer_id, default_payment_method, backup_payment_methods
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (tenant_id, customer_id) DO UPDATE
+SET default_payment_method = EXCLUDED.default_payment_method,
+    backup_payment_methods = EXCLUDED.backup_payment_methods
+RETURNING *;
+
+-- name: GetCustomerBalance :one
+SELECT * FROM customer_balances
+WHERE tenant_id = $1 AND customer_id = $2 AND currency = $3 LIMIT 1;
+
+-- name: ListCustomerBalances :many
+SELECT * FROM customer_balances
+WHERE tenant_id = $1 AND customer_id = $2
+ORDER BY currency;
+
+-- name: AdjustCustomerBalance :one
+INSERT INTO customer_balances (
+    tenant_id, customer_id, currency, amount
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (tenant_id, customer_id, currency) DO UPDATE
+SET amount = customer_balances.amount + EXCLUDED.amount
+WHERE customer_balances.amount + EXCLUDED.amount >= 0
+RETURNING *;
+
+-- name: CreateCustomerBalanceTransaction :one
+INSERT INTO customer_balance_transactions (
+    id, tenant_id, customer_id, type, reason, amount, currency, balance_after,
+    description, source_id, provider_transaction_id, created_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+)
+RETURNING *;
+
+-- name: ListCustomerBalanceTransactions :many
+SELECT * FROM customer_balance_transactions
+WHERE tenant_id = $1 AND customer_id = $2
+ORDER BY created_at DESC, id DESC
+LIMIT $3;
+
+-- name: GetCustomerBalanceTransactionBySource :one
+SELECT * FROM customer_balance_transactions
+WHERE tenant_id = $1 AND type = $2 AND source_id = $3
+ORDER BY created_at LIMIT 1;
+
+-- name: SumCustomerBalanceTransactionsBySource :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS total FROM customer_balance_transactions
+WHERE tenant_id = $1 AND type = $2 AND source_id = $3;
+
+-- name: SetCustomerBalanceTransactionSource :exec
+UPDATE customer_balance_transactions
+SET source_id = $2
+WHERE id = $1;
@@ -8,428 +8,328 @@ package sqlc
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"time"
 
 	"github.com/sqlc-dev/pqtype"
 )
 
-const CreateCharge = `-- name: CreateCharge :one
-INSERT INTO charges (
-    id, amount, currency, status, customer_id, payment_method_id, description, metadata
-) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8
-) RETURNING id, amount, currency, status, customer_id, payment_method_id, description, metadata, created_at, updated_at
+const AbandonJobRuns = `-- name: AbandonJobRuns :exec
+UPDATE job_runs
+SET status = 'abandoned', finished_at = $2
+WHERE job_name = $1 AND status = 'running'
 `
 
-type CreateChargeParams struct {
-	ID              string                `json:"id"`
-	Amount          int64                 `json:"amount"`
-	Currency        string                `json:"currency"`
-	Status          string                `json:"status"`
-	CustomerID      string                `json:"customer_id"`
-	PaymentMethodID sql.NullString        `json:"payment_method_id"`
-	Description     sql.NullString        `json:"description"`
-	Metadata        pqtype.NullRawMessage `json:"metadata"`
+type AbandonJobRunsParams struct {
+	JobName    string       `json:"job_name"`
+	FinishedAt sql.NullTime `json:"finished_at"`
 }
 
-func (q *Queries) CreateCharge(ctx context.Context, db DBTX, arg CreateChargeParams) (Charge, error) {
-	row := db.QueryRowContext(ctx, CreateCharge,
-		arg.ID,
-		arg.Amount,
-		arg.Currency,
-		arg.Status,
-		arg.CustomerID,
-		arg.PaymentMethodID,
-		arg.Description,
-		arg.Metadata,
-	)
-	var i Charge
-	err := row.Scan(
-		&i.ID,
-		&i.Amount,
-		&i.Currency,
-		&i.Status,
-		&i.CustomerID,
-		&i.PaymentMethodID,
-		&i.Description,
-		&i.Metadata,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-	)
-	return i, err
+func (q *Queries) AbandonJobRuns(ctx context.Context, db DBTX, arg AbandonJobRunsParams) error {
+	_, err := db.ExecContext(ctx, AbandonJobRuns, arg.JobName, arg.FinishedAt)
+	return err
 }
 
-const CreateCustomer = `-- name: CreateCustomer :one
-INSERT INTO customers (
-    id, email, name, phone, description, metadata
-) VALUES (
-    $1, $2, $3, $4, $5, $6
-) RETURNING id, email, name, phone, description, metadata, created_at, updated_at
+const AddPaymentLinkConversion = `-- name: AddPaymentLinkConversion :one
+UPDATE payment_links
+SET conversions = conversions + 1, amount_collected = $2 + amount_collected
+WHERE id = $1
+RETURNING id, url, amount, currency, product_name, price_id, active, expires_at, max_uses, conversions, amount_collected, metadata, deactivated_at, created_at, updated_at, tenant_id
 `
 
-type CreateCustomerParams struct {
-	ID          string                `json:"id"`
-	Email       string                `json:"email"`
-	Name        string                `json:"name"`
-	Phone       sql.NullString        `json:"phone"`
-	Description sql.NullString        `json:"description"`
-	Metadata    pqtype.NullRawMessage `json:"metadata"`
+type AddPaymentLinkConversionParams struct {
+	ID              string `json:"id"`
+	AmountCollected int64  `json:"amount_collected"`
 }
 
-func (q *Queries) CreateCustomer(ctx context.Context, db DBTX, arg CreateCustomerParams) (Customer, error) {
-	row := db.QueryRowContext(ctx, CreateCustomer,
-		arg.ID,
-		arg.Email,
-		arg.Name,
-		arg.Phone,
-		arg.Description,
-		arg.Metadata,
-	)
-	var i Customer
+func (q *Queries) AddPaymentLinkConversion(ctx context.Context, db DBTX, arg AddPaymentLinkConversionParams) (PaymentLink, error) {
+	row := db.QueryRowContext(ctx, AddPaymentLinkConversion, arg.ID, arg.AmountCollected)
+	var i PaymentLink
 	err := row.Scan(
 		&i.ID,
-		&i.Email,
-		&i.Name,
-		&i.Phone,
-		&i.Description,
+		&i.Url,
+		&i.Amount,
+		&i.Currency,
+		&i.ProductName,
+		&i.PriceID,
+		&i.Active,
+		&i.ExpiresAt,
+		&i.MaxUses,
+		&i.Conversions,
+		&i.AmountCollected,
 		&i.Metadata,
+		&i.DeactivatedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.TenantID,
 	)
 	return i, err
 }
 
-const CreatePaymentMethod = `-- name: CreatePaymentMethod :one
-INSERT INTO payment_methods (
-    id, type, customer_id, card_last4, card_brand, card_exp_month, card_exp_year, card_fingerprint, metadata
+const AddTenantSpend = `-- name: AddTenantSpend :one
+INSERT INTO tenant_spend (
+    tenant_id, period, amount, charge_count
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8, $9
-) RETURNING id, type, customer_id, card_last4, card_brand, card_exp_month, card_exp_year, card_fingerprint, metadata, created_at
+    $1, $2, $3, 1
+)
+ON CONFLICT (tenant_id, period) DO UPDATE
+SET amount = tenant_spend.amount + EXCLUDED.amount,
+    charge_count = tenant_spend.charge_count + 1
+RETURNING tenant_id, period, amount, charge_count
 `
 
-type CreatePaymentMethodParams struct {
-	ID              string                `json:"id"`
-	Type            string                `json:"type"`
-	CustomerID      string                `json:"customer_id"`
-	CardLast4       sql.NullString        `json:"card_last4"`
-	CardBrand       sql.NullString        `json:"card_brand"`
-	CardExpMonth    sql.NullInt32         `json:"card_exp_month"`
-	CardExpYear     sql.NullInt32         `json:"card_exp_year"`
-	CardFingerprint sql.NullString        `json:"card_fingerprint"`
-	Metadata        pqtype.NullRawMessage `json:"metadata"`
+type AddTenantSpendParams struct {
+	TenantID string `json:"tenant_id"`
+	Period   string `json:"period"`
+	Amount   int64  `json:"amount"`
 }
 
-func (q *Queries) CreatePaymentMethod(ctx context.Context, db DBTX, arg CreatePaymentMethodParams) (PaymentMethod, error) {
-	row := db.QueryRowContext(ctx, CreatePaymentMethod,
-		arg.ID,
-		arg.Type,
-		arg.CustomerID,
-		arg.CardLast4,
-		arg.CardBrand,
-		arg.CardExpMonth,
-		arg.CardExpYear,
-		arg.CardFingerprint,
-		arg.Metadata,
-	)
-	var i PaymentMethod
+func (q *Queries) AddTenantSpend(ctx context.Context, db DBTX, arg AddTenantSpendParams) (TenantSpend, error) {
+	row := db.QueryRowContext(ctx, AddTenantSpend, arg.TenantID, arg.Period, arg.Amount)
+	var i TenantSpend
 	err := row.Scan(
-		&i.ID,
-		&i.Type,
-		&i.CustomerID,
-		&i.CardLast4,
-		&i.CardBrand,
-		&i.CardExpMonth,
-		&i.CardExpYear,
-		&i.CardFingerprint,
-		&i.Metadata,
-		&i.CreatedAt,
+		&i.TenantID,
+		&i.Period,
+		&i.Amount,
+		&i.ChargeCount,
 	)
 	return i, err
 }
 
-const CreateRefund = `-- name: CreateRefund :one
-INSERT INTO refunds (
-    id, charge_id, amount, currency, status, reason, metadata
+const AdjustCustomerBalance = `-- name: AdjustCustomerBalance :one
+INSERT INTO customer_balances (
+    tenant_id, customer_id, currency, amount
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7
-) RETURNING id, charge_id, amount, currency, status, reason, metadata, created_at, updated_at
+    $1, $2, $3, $4
+)
+ON CONFLICT (tenant_id, customer_id, currency) DO UPDATE
+SET amount = customer_balances.amount + EXCLUDED.amount
+WHERE customer_balances.amount + EXCLUDED.amount >= 0
+RETURNING tenant_id, customer_id, currency, amount, created_at, updated_at
 `
 
-type CreateRefundParams struct {
-	ID       string                `json:"id"`
-	ChargeID string                `json:"charge_id"`
-	Amount   int64                 `json:"amount"`
-	Currency string                `json:"currency"`
-	Status   string                `json:"status"`
-	Reason   sql.NullString        `json:"reason"`
-	Metadata pqtype.NullRawMessage `json:"metadata"`
+type AdjustCustomerBalanceParams struct {
+	TenantID   string `json:"tenant_id"`
+	CustomerID string `json:"customer_id"`
+	Currency   string `json:"currency"`
+	Amount     int64  `json:"amount"`
 }
 
-func (q *Queries) CreateRefund(ctx context.Context, db DBTX, arg CreateRefundParams) (Refund, error) {
-	row := db.QueryRowContext(ctx, CreateRefund,
-		arg.ID,
-		arg.ChargeID,
-		arg.Amount,
+func (q *Queries) AdjustCustomerBalance(ctx context.Context, db DBTX, arg AdjustCustomerBalanceParams) (CustomerBalance, error) {
+	row := db.QueryRowContext(ctx, AdjustCustomerBalance,
+		arg.TenantID,
+		arg.CustomerID,
 		arg.Currency,
-		arg.Status,
-		arg.Reason,
-		arg.Metadata,
+		arg.Amount,
 	)
-	var i Refund
+	var i CustomerBalance
 	err := row.Scan(
-		&i.ID,
-		&i.ChargeID,
-		&i.Amount,
+		&i.TenantID,
+		&i.CustomerID,
 		&i.Currency,
-		&i.Status,
-		&i.Reason,
-		&i.Metadata,
+		&i.Amount,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const DeleteCustomer = `-- name: DeleteCustomer :exec
-DELETE FROM customers
-WHERE id = $1
+const AnonymizeChargeListRows = `-- name: AnonymizeChargeListRows :exec
+UPDATE charge_list_rows
+SET customer_email = '',
+    customer_name = ''
+WHERE customer_id = $1
 `
 
-func (q *Queries) DeleteCustomer(ctx context.Context, db DBTX, id string) error {
-	_, err := db.ExecContext(ctx, DeleteCustomer, id)
+func (q *Queries) AnonymizeChargeListRows(ctx context.Context, db DBTX, customerID string) error {
+	_, err := db.ExecContext(ctx, AnonymizeChargeListRows, customerID)
 	return err
 }
 
-const DeletePaymentMethod = `-- name: DeletePaymentMethod :exec
-DELETE FROM payment_methods
-WHERE id = $1 AND customer_id = $2
+const AnonymizeCustomer = `-- name: AnonymizeCustomer :exec
+INSERT INTO customers (
+    id, email, name, tenant_id, synced_at
+) VALUES (
+    $1, '', '', $2, $3
+)
+ON CONFLICT (id) DO UPDATE
+SET email = '',
+    name = '',
+    phone = NULL,
+    description = NULL
 `
 
-type DeletePaymentMethodParams struct {
-	ID         string `json:"id"`
-	CustomerID string `json:"customer_id"`
+type AnonymizeCustomerParams struct {
+	ID       string    `json:"id"`
+	TenantID string    `json:"tenant_id"`
+	SyncedAt time.Time `json:"synced_at"`
 }
 
-func (q *Queries) DeletePaymentMethod(ctx context.Context, db DBTX, arg DeletePaymentMethodParams) error {
-	_, err := db.ExecContext(ctx, DeletePaymentMethod, arg.ID, arg.CustomerID)
+func (q *Queries) AnonymizeCustomer(ctx context.Context, db DBTX, arg AnonymizeCustomerParams) error {
+	_, err := db.ExecContext(ctx, AnonymizeCustomer, arg.ID, arg.TenantID, arg.SyncedAt)
 	return err
 }
 
-const GetCharge = `-- name: GetCharge :one
-SELECT id, amount, currency, status, customer_id, payment_method_id, description, metadata, created_at, updated_at FROM charges
-WHERE id = $1 LIMIT 1
+const AppendChargeTransition = `-- name: AppendChargeTransition :one
+INSERT INTO charge_transitions (
+    id, charge_id, sequence, from_state, to_state, source, event_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+ON CONFLICT (charge_id, sequence) DO NOTHING
+RETURNING id, charge_id, sequence, from_state, to_state, source, event_id, created_at
 `
 
-func (q *Queries) GetCharge(ctx context.Context, db DBTX, id string) (Charge, error) {
-	row := db.QueryRowContext(ctx, GetCharge, id)
-	var i Charge
+type AppendChargeTransitionParams struct {
+	ID        string `json:"id"`
+	ChargeID  string `json:"charge_id"`
+	Sequence  int32  `json:"sequence"`
+	FromState string `json:"from_state"`
+	ToState   string `json:"to_state"`
+	Source    string `json:"source"`
+	EventID   string `json:"event_id"`
+}
+
+func (q *Queries) AppendChargeTransition(ctx context.Context, db DBTX, arg AppendChargeTransitionParams) (ChargeTransition, error) {
+	row := db.QueryRowContext(ctx, AppendChargeTransition,
+		arg.ID,
+		arg.ChargeID,
+		arg.Sequence,
+		arg.FromState,
+		arg.ToState,
+		arg.Source,
+		arg.EventID,
+	)
+	var i ChargeTransition
 	err := row.Scan(
 		&i.ID,
-		&i.Amount,
-		&i.Currency,
-		&i.Status,
-		&i.CustomerID,
-		&i.PaymentMethodID,
-		&i.Description,
-		&i.Metadata,
+		&i.ChargeID,
+		&i.Sequence,
+		&i.FromState,
+		&i.ToState,
+		&i.Source,
+		&i.EventID,
 		&i.CreatedAt,
-		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const GetChargeStats = `-- name: GetChargeStats :one
-SELECT 
-    COUNT(*) as total_charges,
-    SUM(amount) as total_amount,
-    COUNT(CASE WHEN status = 'succeeded' THEN 1 END) as successful_charges,
-    SUM(CASE WHEN status = 'succeeded' THEN amount ELSE 0 END) as successful_amount
-FROM charges
+const ArchiveEvent = `-- name: ArchiveEvent :exec
+INSERT INTO event_archive (
+    id, tenant_id, type, subject, occurred_at, payload
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+ON CONFLICT (id) DO NOTHING
 `
 
-type GetChargeStatsRow struct {
-	TotalCharges      int64 `json:"total_charges"`
-	TotalAmount       int64 `json:"total_amount"`
-	SuccessfulCharges int64 `json:"successful_charges"`
-	SuccessfulAmount  int64 `json:"successful_amount"`
+type ArchiveEventParams struct {
+	ID         string          `json:"id"`
+	TenantID   string          `json:"tenant_id"`
+	Type       string          `json:"type"`
+	Subject    string          `json:"subject"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Payload    json.RawMessage `json:"payload"`
 }
 
-func (q *Queries) GetChargeStats(ctx context.Context, db DBTX) (GetChargeStatsRow, error) {
-	row := db.QueryRowContext(ctx, GetChargeStats)
-	var i GetChargeStatsRow
-	err := row.Scan(
-		&i.TotalCharges,
-		&i.TotalAmount,
-		&i.SuccessfulCharges,
-		&i.SuccessfulAmount,
+func (q *Queries) ArchiveEvent(ctx context.Context, db DBTX, arg ArchiveEventParams) error {
+	_, err := db.ExecContext(ctx, ArchiveEvent,
+		arg.ID,
+		arg.TenantID,
+		arg.Type,
+		arg.Subject,
+		arg.OccurredAt,
+		arg.Payload,
 	)
-	return i, err
+	return err
 }
 
-const GetCustomer = `-- name: GetCustomer :one
-SELECT id, email, name, phone, description, metadata, created_at, updated_at FROM customers
-WHERE id = $1 LIMIT 1
+const ClaimCustomerDeletion = `-- name: ClaimCustomerDeletion :one
+UPDATE customer_deletions
+SET purged_at = $2
+WHERE customer_id = $1 AND purged_at IS NULL
+RETURNING customer_id, tenant_id, deleted_at, purge_after, purged_at, created_at
 `
 
-func (q *Queries) GetCustomer(ctx context.Context, db DBTX, id string) (Customer, error) {
-	row := db.QueryRowContext(ctx, GetCustomer, id)
-	var i Customer
-	err := row.Scan(
-		&i.ID,
-		&i.Email,
-		&i.Name,
-		&i.Phone,
-		&i.Description,
-		&i.Metadata,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-	)
-	return i, err
+type ClaimCustomerDeletionParams struct {
+	CustomerID string       `json:"customer_id"`
+	PurgedAt   sql.NullTime `json:"purged_at"`
 }
 
-const GetCustomerByEmail = `-- name: GetCustomerByEmail :one
-SELECT id, email, name, phone, description, metadata, created_at, updated_at FROM customers
-WHERE email = $1 LIMIT 1
-`
-
-func (q *Queries) GetCustomerByEmail(ctx context.Context, db DBTX, email string) (Customer, error) {
-	row := db.QueryRowContext(ctx, GetCustomerByEmail, email)
-	var i Customer
+func (q *Queries) ClaimCustomerDeletion(ctx context.Context, db DBTX, arg ClaimCustomerDeletionParams) (CustomerDeletion, error) {
+	row := db.QueryRowContext(ctx, ClaimCustomerDeletion, arg.CustomerID, arg.PurgedAt)
+	var i CustomerDeletion
 	err := row.Scan(
-		&i.ID,
-		&i.Email,
-		&i.Name,
-		&i.Phone,
-		&i.Description,
-		&i.Metadata,
+		&i.CustomerID,
+		&i.TenantID,
+		&i.DeletedAt,
+		&i.PurgeAfter,
+		&i.PurgedAt,
 		&i.CreatedAt,
-		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const GetCustomerStats = `-- name: GetCustomerStats :one
-SELECT 
-    COUNT(*) as total_customers,
-    COUNT(CASE WHEN created_at >= NOW() - INTERVAL '30 days' THEN 1 END) as new_customers_30d,
-    COUNT(CASE WHEN created_at >= NOW() - INTERVAL '7 days' THEN 1 END) as new_customers_7d
-FROM customers
+const ClaimDisputeEvidenceReminder = `-- name: ClaimDisputeEvidenceReminder :execrows
+INSERT INTO dispute_evidence_reminders (
+    dispute_id, due_by
+) VALUES (
+    $1, $2
+)
+ON CONFLICT (dispute_id, due_by) DO NOTHING
 `
 
-type GetCustomerStatsRow struct {
-	TotalCustomers  int64 `json:"total_customers"`
-	NewCustomers30d int64 `json:"new_customers_30d"`
-	NewCustomers7d  int64 `json:"new_customers_7d"`
+type ClaimDisputeEvidenceReminderParams struct {
+	DisputeID string    `json:"dispute_id"`
+	DueBy     time.Time `json:"due_by"`
 }
 
-func (q *Queries) GetCustomerStats(ctx context.Context, db DBTX) (GetCustomerStatsRow, error) {
-	row := db.QueryRowContext(ctx, GetCustomerStats)
-	var i GetCustomerStatsRow
-	err := row.Scan(&i.TotalCustomers, &i.NewCustomers30d, &i.NewCustomers7d)
-	return i, err
+func (q *Queries) ClaimDisputeEvidenceReminder(ctx context.Context, db DBTX, arg ClaimDisputeEvidenceReminderParams) (int64, error) {
+	result, err := db.ExecContext(ctx, ClaimDisputeEvidenceReminder, arg.DisputeID, arg.DueBy)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
-const GetPaymentMethod = `-- name: GetPaymentMethod :one
-SELECT id, type, customer_id, card_last4, card_brand, card_exp_month, card_exp_year, card_fingerprint, metadata, created_at FROM payment_methods
-WHERE id = $1 LIMIT 1
+const ClaimDueDeadLetters = `-- name: ClaimDueDeadLetters :many
+UPDATE dlq_events
+SET next_attempt_at = $1
+WHERE id IN (
+    SELECT id FROM dlq_events
+    WHERE status = 'pending' AND next_attempt_at <= NOW()
+    ORDER BY next_attempt_at
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, source, dedupe_key, payload, headers, status, attempts, last_error, next_attempt_at, resolved_at, created_at, updated_at
 `
 
-func (q *Queries) GetPaymentMethod(ctx context.Context, db DBTX, id string) (PaymentMethod, error) {
-	row := db.QueryRowContext(ctx, GetPaymentMethod, id)
-	var i PaymentMethod
-	err := row.Scan(
-		&i.ID,
-		&i.Type,
-		&i.CustomerID,
-		&i.CardLast4,
-		&i.CardBrand,
-		&i.CardExpMonth,
-		&i.CardExpYear,
-		&i.CardFingerprint,
-		&i.Metadata,
-		&i.CreatedAt,
-	)
-	return i, err
-}
-
-const GetRefund = `-- name: GetRefund :one
-SELECT id, charge_id, amount, currency, status, reason, metadata, created_at, updated_at FROM refunds
-WHERE id = $1 LIMIT 1
-`
-
-func (q *Queries) GetRefund(ctx context.Context, db DBTX, id string) (Refund, error) {
-	row := db.QueryRowContext(ctx, GetRefund, id)
-	var i Refund
-	err := row.Scan(
-		&i.ID,
-		&i.ChargeID,
-		&i.Amount,
-		&i.Currency,
-		&i.Status,
-		&i.Reason,
-		&i.Metadata,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-	)
-	return i, err
-}
-
-const GetRefundStats = `-- name: GetRefundStats :one
-SELECT 
-    COUNT(*) as total_refunds,
-    SUM(amount) as total_amount,
-    COUNT(CASE WHEN status = 'succeeded' THEN 1 END) as successful_refunds,
-    SUM(CASE WHEN status = 'succeeded' THEN amount ELSE 0 END) as successful_amount
-FROM refunds
-`
-
-type GetRefundStatsRow struct {
-	TotalRefunds      int64 `json:"total_refunds"`
-	TotalAmount       int64 `json:"total_amount"`
-	SuccessfulRefunds int64 `json:"successful_refunds"`
-	SuccessfulAmount  int64 `json:"successful_amount"`
-}
-
-func (q *Queries) GetRefundStats(ctx context.Context, db DBTX) (GetRefundStatsRow, error) {
-	row := db.QueryRowContext(ctx, GetRefundStats)
-	var i GetRefundStatsRow
-	err := row.Scan(
-		&i.TotalRefunds,
-		&i.TotalAmount,
-		&i.SuccessfulRefunds,
-		&i.SuccessfulAmount,
-	)
-	return i, err
-}
-
-const ListAllCharges = `-- name: ListAllCharges :many
-SELECT id, amount, currency, status, customer_id, payment_method_id, description, metadata, created_at, updated_at FROM charges
-ORDER BY created_at DESC
-LIMIT $1 OFFSET $2
-`
-
-type ListAllChargesParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+type ClaimDueDeadLettersParams struct {
+	NextAttemptAt sql.NullTime `json:"next_attempt_at"`
+	Limit         int32        `json:"limit"`
 }
 
-func (q *Queries) ListAllCharges(ctx context.Context, db DBTX, arg ListAllChargesParams) ([]Charge, error) {
-	rows, err := db.QueryContext(ctx, ListAllCharges, arg.Limit, arg.Offset)
+func (q *Queries) ClaimDueDeadLetters(ctx context.Context, db DBTX, arg ClaimDueDeadLettersParams) ([]DlqEvent, error) {
+	rows, err := db.QueryContext(ctx, ClaimDueDeadLetters, arg.NextAttemptAt, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []Charge{}
+	items := []DlqEvent{}
 	for rows.Next() {
-		var i Charge
+		var i DlqEvent
 		if err := rows.Scan(
 			&i.ID,
-			&i.Amount,
-			&i.Currency,
+			&i.Source,
+			&i.DedupeKey,
+			&i.Payload,
+			&i.Headers,
 			&i.Status,
-			&i.CustomerID,
-			&i.PaymentMethodID,
-			&i.Description,
-			&i.Metadata,
+			&i.Attempts,
+			&i.LastError,
+			&i.NextAttemptAt,
+			&i.ResolvedAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
 		); err != nil {
@@ -446,34 +346,46 @@ func (q *Queries) ListAllCharges(ctx context.Context, db DBTX, arg ListAllCharge
 	return items, nil
 }
 
-const ListAllRefunds = `-- name: ListAllRefunds :many
-SELECT id, charge_id, amount, currency, status, reason, metadata, created_at, updated_at FROM refunds
-ORDER BY created_at DESC
-LIMIT $1 OFFSET $2
+const ClaimDueWebhookDeliveries = `-- name: ClaimDueWebhookDeliveries :many
+UPDATE webhook_deliveries
+SET next_attempt_at = $1
+WHERE id IN (
+    SELECT id FROM webhook_deliveries
+    WHERE status = 'pending' AND next_attempt_at <= NOW()
+    ORDER BY next_attempt_at
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, tenant_id, endpoint_id, event_id, event_type, payload, status, attempts, last_status_code, last_error, next_attempt_at, delivered_at, created_at, updated_at
 `
 
-type ListAllRefundsParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+type ClaimDueWebhookDeliveriesParams struct {
+	NextAttemptAt sql.NullTime `json:"next_attempt_at"`
+	Limit         int32        `json:"limit"`
 }
 
-func (q *Queries) ListAllRefunds(ctx context.Context, db DBTX, arg ListAllRefundsParams) ([]Refund, error) {
-	rows, err := db.QueryContext(ctx, ListAllRefunds, arg.Limit, arg.Offset)
+func (q *Queries) ClaimDueWebhookDeliveries(ctx context.Context, db DBTX, arg ClaimDueWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	rows, err := db.QueryContext(ctx, ClaimDueWebhookDeliveries, arg.NextAttemptAt, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []Refund{}
+	items := []WebhookDelivery{}
 	for rows.Next() {
-		var i Refund
+		var i WebhookDelivery
 		if err := rows.Scan(
 			&i.ID,
-			&i.ChargeID,
-			&i.Amount,
-			&i.Currency,
+			&i.TenantID,
+			&i.EndpointID,
+			&i.EventID,
+			&i.EventType,
+			&i.Payload,
 			&i.Status,
-			&i.Reason,
-			&i.Metadata,
+			&i.Attempts,
+			&i.LastStatusCode,
+			&i.LastError,
+			&i.NextAttemptAt,
+			&i.DeliveredAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
 		); err != nil {
@@ -490,196 +402,490 @@ func (q *Queries) ListAllRefunds(ctx context.Context, db DBTX, arg ListAllRefund
 	return items, nil
 }
 
-const ListCharges = `-- name: ListCharges :many
-SELECT id, amount, currency, status, customer_id, payment_method_id, description, metadata, created_at, updated_at FROM charges
-WHERE customer_id = $1
-ORDER BY created_at DESC
-LIMIT $2 OFFSET $3
+const ClaimOffboardingExport = `-- name: ClaimOffboardingExport :one
+UPDATE offboarding_exports
+SET status = 'running', started_at = NOW()
+WHERE id = $1 AND (status = 'pending' OR (status = 'running' AND started_at < NOW() - INTERVAL '1 hour'))
+RETURNING id, tenant_id, status, recipient_public_key, manifest, archive_sha256, archive_size, error, pan_migration_status, pan_migration_destination, pan_migration_reference, pan_migration_requested_at, started_at, completed_at, created_at, updated_at
 `
 
-type ListChargesParams struct {
-	CustomerID string `json:"customer_id"`
-	Limit      int32  `json:"limit"`
-	Offset     int32  `json:"offset"`
+func (q *Queries) ClaimOffboardingExport(ctx context.Context, db DBTX, id string) (OffboardingExport, error) {
+	row := db.QueryRowContext(ctx, ClaimOffboardingExport, id)
+	var i OffboardingExport
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.Status,
+		&i.RecipientPublicKey,
+		&i.Manifest,
+		&i.ArchiveSha256,
+		&i.ArchiveSize,
+		&i.Error,
+		&i.PanMigrationStatus,
+		&i.PanMigrationDestination,
+		&i.PanMigrationReference,
+		&i.PanMigrationRequestedAt,
+		&i.StartedAt,
+		&i.CompletedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
 }
 
-func (q *Queries) ListCharges(ctx context.Context, db DBTX, arg ListChargesParams) ([]Charge, error) {
-	rows, err := db.QueryContext(ctx, ListCharges, arg.CustomerID, arg.Limit, arg.Offset)
+const ClaimRefundApproval = `-- name: ClaimRefundApproval :execrows
+UPDATE refund_approvals
+SET status = 'approving', decided_by = $2, updated_at = NOW()
+WHERE id = $1 AND status = 'pending' AND ($3 = '' OR tenant_id = $3)
+`
+
+type ClaimRefundApprovalParams struct {
+	ID        string         `json:"id"`
+	DecidedBy sql.NullString `json:"decided_by"`
+	TenantID  string         `json:"tenant_id"`
+}
+
+func (q *Queries) ClaimRefundApproval(ctx context.Context, db DBTX, arg ClaimRefundApprovalParams) (int64, error) {
+	result, err := db.ExecContext(ctx, ClaimRefundApproval, arg.ID, arg.DecidedBy, arg.TenantID)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	items := []Charge{}
-	for rows.Next() {
-		var i Charge
-		if err := rows.Scan(
-			&i.ID,
-			&i.Amount,
-			&i.Currency,
-			&i.Status,
-			&i.CustomerID,
-			&i.PaymentMethodID,
-			&i.Description,
-			&i.Metadata,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected()
 }
 
-const ListCustomers = `-- name: ListCustomers :many
-SELECT id, email, name, phone, description, metadata, created_at, updated_at FROM customers
-ORDER BY created_at DESC
-LIMIT $1 OFFSET $2
+const ClaimRefundBatch = `-- name: ClaimRefundBatch :one
+UPDATE refund_batches
+SET status = 'running', started_at = NOW()
+WHERE id = $1 AND (status = 'pending' OR (status = 'running' AND started_at < NOW() - INTERVAL '1 hour'))
+RETURNING id, tenant_id, api_key_id, operator_id, requests, status, scheduled_at, started_at, completed_at, created_at, updated_at
 `
 
-type ListCustomersParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+func (q *Queries) ClaimRefundBatch(ctx context.Context, db DBTX, id string) (RefundBatch, error) {
+	row := db.QueryRowContext(ctx, ClaimRefundBatch, id)
+	var i RefundBatch
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.ApiKeyID,
+		&i.OperatorID,
+		&i.Requests,
+		&i.Status,
+		&i.ScheduledAt,
+		&i.StartedAt,
+		&i.CompletedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
 }
 
-func (q *Queries) ListCustomers(ctx context.Context, db DBTX, arg ListCustomersParams) ([]Customer, error) {
-	rows, err := db.QueryContext(ctx, ListCustomers, arg.Limit, arg.Offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	items := []Customer{}
-	for rows.Next() {
-		var i Customer
-		if err := rows.Scan(
-			&i.ID,
-			&i.Email,
-			&i.Name,
-			&i.Phone,
-			&i.Description,
-			&i.Metadata,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+const CompleteOffboardingExport = `-- name: CompleteOffboardingExport :one
+UPDATE offboarding_exports
+SET status = 'completed', manifest = $1, archive_sha256 = $2, archive_size = $3, completed_at = NOW()
+WHERE id = $4 AND status = 'running'
+RETURNING id, tenant_id, status, recipient_public_key, manifest, archive_sha256, archive_size, error, pan_migration_status, pan_migration_destination, pan_migration_reference, pan_migration_requested_at, started_at, completed_at, created_at, updated_at
+`
+
+type CompleteOffboardingExportParams struct {
+	Manifest      pqtype.NullRawMessage `json:"manifest"`
+	ArchiveSha256 string                `json:"archive_sha256"`
+	ArchiveSize   int64                 `json:"archive_size"`
+	ID            string                `json:"id"`
 }
 
-const ListPaymentMethods = `-- name: ListPaymentMethods :many
-SELECT id, type, customer_id, card_last4, card_brand, card_exp_month, card_exp_year, card_fingerprint, metadata, created_at FROM payment_methods
-WHERE customer_id = $1
-ORDER BY created_at DESC
+func (q *Queries) CompleteOffboardingExport(ctx context.Context, db DBTX, arg CompleteOffboardingExportParams) (OffboardingExport, error) {
+	row := db.QueryRowContext(ctx, CompleteOffboardingExport,
+		arg.Manifest,
+		arg.ArchiveSha256,
+		arg.ArchiveSize,
+		arg.ID,
+	)
+	var i OffboardingExport
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.Status,
+		&i.RecipientPublicKey,
+		&i.Manifest,
+		&i.ArchiveSha256,
+		&i.ArchiveSize,
+		&i.Error,
+		&i.PanMigrationStatus,
+		&i.PanMigrationDestination,
+		&i.PanMigrationReference,
+		&i.PanMigrationRequestedAt,
+		&i.StartedAt,
+		&i.CompletedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const CompleteReconciliationRun = `-- name: CompleteReconciliationRun :one
+UPDATE reconciliation_runs
+SET status = $1,
+    transactions_checked = $2,
+    payouts_checked = $3,
+    matched = $4,
+    mismatches = $5,
+    error = $6,
+    completed_at = $7
+WHERE id = $8
+RETURNING id, status, period_start, period_end, transactions_checked, payouts_checked, matched, mismatches, error, started_at, completed_at
 `
 
-func (q *Queries) ListPaymentMethods(ctx context.Context, db DBTX, customerID string) ([]PaymentMethod, error) {
-	rows, err := db.QueryContext(ctx, ListPaymentMethods, customerID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	items := []PaymentMethod{}
-	for rows.Next() {
-		var i PaymentMethod
-		if err := rows.Scan(
-			&i.ID,
-			&i.Type,
-			&i.CustomerID,
-			&i.CardLast4,
-			&i.CardBrand,
-			&i.CardExpMonth,
-			&i.CardExpYear,
-			&i.CardFingerprint,
-			&i.Metadata,
-			&i.CreatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+type CompleteReconciliationRunParams struct {
+	Status              string       `json:"status"`
+	TransactionsChecked int64        `json:"transactions_checked"`
+	PayoutsChecked      int64        `json:"payouts_checked"`
+	Matched             int64        `json:"matched"`
+	Mismatches          int64        `json:"mismatches"`
+	Error               string       `json:"error"`
+	CompletedAt         sql.NullTime `json:"completed_at"`
+	ID                  string       `json:"id"`
 }
 
-const ListRefunds = `-- name: ListRefunds :many
-SELECT id, charge_id, amount, currency, status, reason, metadata, created_at, updated_at FROM refunds
-WHERE charge_id = $1
-ORDER BY created_at DESC
-LIMIT $2 OFFSET $3
+func (q *Queries) CompleteReconciliationRun(ctx context.Context, db DBTX, arg CompleteReconciliationRunParams) (ReconciliationRun, error) {
+	row := db.QueryRowContext(ctx, CompleteReconciliationRun,
+		arg.Status,
+		arg.TransactionsChecked,
+		arg.PayoutsChecked,
+		arg.Matched,
+		arg.Mismatches,
+		arg.Error,
+		arg.CompletedAt,
+		arg.ID,
+	)
+	var i ReconciliationRun
+	err := row.Scan(
+		&i.ID,
+		&i.Status,
+		&i.PeriodStart,
+		&i.PeriodEnd,
+		&i.TransactionsChecked,
+		&i.PayoutsChecked,
+		&i.Matched,
+		&i.Mismatches,
+		&i.Error,
+		&i.StartedAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
+
+const CountFingerprintChargebacks = `-- name: CountFingerprintChargebacks :one
+SELECT COUNT(*) AS chargeback_count
+FROM disputes
+WHERE disputed_at >= $1::timestamptz
+  AND status NOT IN ('won', 'warning_closed')
+  AND EXISTS (
+      SELECT 1 FROM charges, payment_methods
+      WHERE charges.id = disputes.charge_id
+        AND payment_methods.id = charges.payment_method_id
+        AND charges.tenant_id = $2::text
+        AND payment_methods.card_fingerprint = $3::text
+  )
 `
 
-type ListRefundsParams struct {
-	ChargeID string `json:"charge_id"`
-	Limit    int32  `json:"limit"`
-	Offset   int32  `json:"offset"`
+type CountFingerprintChargebacksParams struct {
+	Since           time.Time `json:"since"`
+	TenantID        string    `json:"tenant_id"`
+	CardFingerprint string    `json:"card_fingerprint"`
 }
 
-func (q *Queries) ListRefunds(ctx context.Context, db DBTX, arg ListRefundsParams) ([]Refund, error) {
-	rows, err := db.QueryContext(ctx, ListRefunds, arg.ChargeID, arg.Limit, arg.Offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	items := []Refund{}
-	for rows.Next() {
-		var i Refund
-		if err := rows.Scan(
-			&i.ID,
-			&i.ChargeID,
-			&i.Amount,
-			&i.Currency,
-			&i.Status,
-			&i.Reason,
-			&i.Metadata,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+func (q *Queries) CountFingerprintChargebacks(ctx context.Context, db DBTX, arg CountFingerprintChargebacksParams) (int64, error) {
+	row := db.QueryRowContext(ctx, CountFingerprintChargebacks, arg.Since, arg.TenantID, arg.CardFingerprint)
+	var chargeback_count int64
+	err := row.Scan(&chargeback_count)
+	return chargeback_count, err
+}
+
+const CountFingerprintCustomers = `-- name: CountFingerprintCustomers :one
+SELECT COUNT(DISTINCT customer_id) AS customer_count
+FROM payment_methods
+WHERE tenant_id = $1
+  AND card_fingerprint = $2
+  AND customer_id <> $3
+`
+
+type CountFingerprintCustomersParams struct {
+	TenantID        string         `json:"tenant_id"`
+	CardFingerprint sql.NullString `json:"card_fingerprint"`
+	CustomerID      string         `json:"customer_id"`
+}
+
+func (q *Queries) CountFingerprintCustomers(ctx context.Context, db DBTX, arg CountFingerprintCustomersParams) (int64, error) {
+	row := db.QueryRowContext(ctx, CountFingerprintCustomers, arg.TenantID, arg.CardFingerprint, arg.CustomerID)
+	var customer_count int64
+	err := row.Scan(&customer_count)
+	return customer_count, err
+}
+
+const CountRecentFraudScreenings = `-- name: CountRecentFraudScreenings :one
+SELECT
+    COUNT(*) FILTER (WHERE $1::text <> '' AND customer_id = $1) AS customer_count,
+    COUNT(*) FILTER (WHERE $2::text <> '' AND card_fingerprint = $2) AS card_count,
+    COUNT(*) FILTER (WHERE $3::text <> '' AND ip_address = $3) AS ip_count
+FROM fraud_screenings
+WHERE created_at >= $4
+`
+
+type CountRecentFraudScreeningsParams struct {
+	CustomerID      string       `json:"customer_id"`
+	CardFingerprint string       `json:"card_fingerprint"`
+	IpAddress       string       `json:"ip_address"`
+	Since           sql.NullTime `json:"since"`
+}
+
+type CountRecentFraudScreeningsRow struct {
+	CustomerCount int64 `json:"customer_count"`
+	CardCount     int64 `json:"card_count"`
+	IpCount       int64 `json:"ip_count"`
+}
+
+func (q *Queries) CountRecentFraudScreenings(ctx context.Context, db DBTX, arg CountRecentFraudScreeningsParams) (CountRecentFraudScreeningsRow, error) {
+	row := db.QueryRowContext(ctx, CountRecentFraudScreenings,
+		arg.CustomerID,
+		arg.CardFingerprint,
+		arg.IpAddress,
+		arg.Since,
+	)
+	var i CountRecentFraudScreeningsRow
+	err := row.Scan(&i.CustomerCount, &i.CardCount, &i.IpCount)
+	return i, err
+}
+
+const CreateAPIKey = `-- name: CreateAPIKey :one
+INSERT INTO api_keys (
+    id, name, secret_hash, secret_hint, scopes, tenant_id, created_by, rotated_from
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING id, name, secret_hash, secret_hint, scopes, tenant_id, created_by, rotated_from, expires_at, revoked_at, created_at
+`
+
+type CreateAPIKeyParams struct {
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	SecretHash  string          `json:"secret_hash"`
+	SecretHint  string          `json:"secret_hint"`
+	Scopes      json.RawMessage `json:"scopes"`
+	TenantID    string          `json:"tenant_id"`
+	CreatedBy   string          `json:"created_by"`
+	RotatedFrom string          `json:"rotated_from"`
+}
+
+func (q *Queries) CreateAPIKey(ctx context.Context, db DBTX, arg CreateAPIKeyParams) (ApiKey, error) {
+	row := db.QueryRowContext(ctx, CreateAPIKey,
+		arg.ID,
+		arg.Name,
+		arg.SecretHash,
+		arg.SecretHint,
+		arg.Scopes,
+		arg.TenantID,
+		arg.CreatedBy,
+		arg.RotatedFrom,
+	)
+	var i ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.SecretHash,
+		&i.SecretHint,
+		&i.Scopes,
+		&i.TenantID,
+		&i.CreatedBy,
+		&i.RotatedFrom,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const CreateAuditEntry = `-- name: CreateAuditEntry :execrows
+INSERT INTO audit_log (
+    sequence, tenant_id, actor_type, actor_id, operator_id, action, resource_type, resource_id,
+    method, path, status, before_state, after_state, changes, ip, request_id, created_at, previous_hash, hash
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19
+)
+ON CONFLICT (sequence) DO NOTHING
+`
+
+type CreateAuditEntryParams struct {
+	Sequence     int64                 `json:"sequence"`
+	TenantID     string                `json:"tenant_id"`
+	ActorType    string                `json:"actor_type"`
+	ActorID      string                `json:"actor_id"`
+	OperatorID   string                `json:"operator_id"`
+	Action       string                `json:"action"`
+	ResourceType string                `json:"resource_type"`
+	ResourceID   string                `json:"resource_id"`
+	Method       string                `json:"method"`
+	Path         string                `json:"path"`
+	Status       int32                 `json:"status"`
+	BeforeState  pqtype.NullRawMessage `json:"before_state"`
+	AfterState   pqtype.NullRawMessage `json:"after_state"`
+	Changes      json.RawMessage       `json:"changes"`
+	Ip           string                `json:"ip"`
+	RequestID    string                `json:"request_id"`
+	CreatedAt    time.Time             `json:"created_at"`
+	PreviousHash string                `json:"previous_hash"`
+	Hash         string                `json:"hash"`
+}
+
+func (q *Queries) CreateAuditEntry(ctx context.Context, db DBTX, arg CreateAuditEntryParams) (int64, error) {
+	result, err := db.ExecContext(ctx, CreateAuditEntry,
+		arg.Sequence,
+		arg.TenantID,
+		arg.ActorType,
+		arg.ActorID,
+		arg.OperatorID,
+		arg.Action,
+		arg.ResourceType,
+		arg.ResourceID,
+		arg.Method,
+		arg.Path,
+		arg.Status,
+		arg.BeforeState,
+		arg.AfterState,
+		arg.Changes,
+		arg.Ip,
+		arg.RequestID,
+		arg.CreatedAt,
+		arg.PreviousHash,
+		arg.Hash,
+	)
+	if err != nil {
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected()
 }
 
-const UpdateChargeStatus = `-- name: UpdateChargeStatus :one
-UPDATE charges
-SET status = $2, updated_at = NOW()
-WHERE id = $1
-RETURNING id, amount, currency, status, customer_id, payment_method_id, description, metadata, created_at, updated_at
+const CreateAutoRefund = `-- name: CreateAutoRefund :one
+INSERT INTO auto_refunds (
+    id, customer_id, currency, amount, refund_id, status, failure_reason, funded_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING id, customer_id, currency, amount, refund_id, status, failure_reason, funded_at, created_at
 `
 
-type UpdateChargeStatusParams struct {
-	ID     string `json:"id"`
-	Status string `json:"status"`
+type CreateAutoRefundParams struct {
+	ID            string    `json:"id"`
+	CustomerID    string    `json:"customer_id"`
+	Currency      string    `json:"currency"`
+	Amount        int64     `json:"amount"`
+	RefundID      string    `json:"refund_id"`
+	Status        string    `json:"status"`
+	FailureReason string    `json:"failure_reason"`
+	FundedAt      time.Time `json:"funded_at"`
 }
 
-func (q *Queries) UpdateChargeStatus(ctx context.Context, db DBTX, arg UpdateChargeStatusParams) (Charge, error) {
-	row := db.QueryRowContext(ctx, UpdateChargeStatus, arg.ID, arg.Status)
+func (q *Queries) CreateAutoRefund(ctx context.Context, db DBTX, arg CreateAutoRefundParams) (AutoRefund, error) {
+	row := db.QueryRowContext(ctx, CreateAutoRefund,
+		arg.ID,
+		arg.CustomerID,
+		arg.Currency,
+		arg.Amount,
+		arg.RefundID,
+		arg.Status,
+		arg.FailureReason,
+		arg.FundedAt,
+	)
+	var i AutoRefund
+	err := row.Scan(
+		&i.ID,
+		&i.CustomerID,
+		&i.Currency,
+		&i.Amount,
+		&i.RefundID,
+		&i.Status,
+		&i.FailureReason,
+		&i.FundedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const CreateBlocklistEntry = `-- name: CreateBlocklistEntry :one
+INSERT INTO blocklist_entries (
+    id, entry_type, value, reason, source, created_by, provider_item_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+ON CONFLICT (entry_type, value) DO UPDATE
+SET entry_type = EXCLUDED.entry_type
+RETURNING id, entry_type, value, reason, source, created_by, provider_item_id, created_at, updated_at
+`
+
+type CreateBlocklistEntryParams struct {
+	ID             string `json:"id"`
+	EntryType      string `json:"entry_type"`
+	Value          string `json:"value"`
+	Reason         string `json:"reason"`
+	Source         string `json:"source"`
+	CreatedBy      string `json:"created_by"`
+	ProviderItemID string `json:"provider_item_id"`
+}
+
+func (q *Queries) CreateBlocklistEntry(ctx context.Context, db DBTX, arg CreateBlocklistEntryParams) (BlocklistEntry, error) {
+	row := db.QueryRowContext(ctx, CreateBlocklistEntry,
+		arg.ID,
+		arg.EntryType,
+		arg.Value,
+		arg.Reason,
+		arg.Source,
+		arg.CreatedBy,
+		arg.ProviderItemID,
+	)
+	var i BlocklistEntry
+	err := row.Scan(
+		&i.ID,
+		&i.EntryType,
+		&i.Value,
+		&i.Reason,
+		&i.Source,
+		&i.CreatedBy,
+		&i.ProviderItemID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const CreateCharge = `-- name: CreateCharge :one
+INSERT INTO charges (
+    id, amount, currency, status, customer_id, payment_method_id, description, metadata
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING id, amount, currency, status, customer_id, payment_method_id, description, metadata, created_at, updated_at, amount_refunded, captured, refunded, disputed, invoice_id, synced_at, tenant_id
+`
+
+type CreateChargeParams struct {
+	ID              string                `json:"id"`
+	Amount          int64                 `json:"amount"`
+	Currency        string                `json:"currency"`
+	Status          string                `json:"status"`
+	CustomerID      string                `json:"customer_id"`
+	PaymentMethodID sql.NullString        `json:"payment_method_id"`
+	Description     sql.NullString        `json:"description"`
+	Metadata        pqtype.NullRawMessage `json:"metadata"`
+}
+
+func (q *Queries) CreateCharge(ctx context.Context, db DBTX, arg CreateChargeParams) (Charge, error) {
+	row := db.QueryRowContext(ctx, CreateCharge,
+		arg.ID,
+		arg.Amount,
+		arg.Currency,
+		arg.Status,
+		arg.CustomerID,
+		arg.PaymentMethodID,
+		arg.Description,
+		arg.Metadata,
+	)
 	var i Charge
 	err := row.Scan(
 		&i.ID,
@@ -692,18 +898,181 @@ func (q *Queries) UpdateChargeStatus(ctx context.Context, db DBTX, arg UpdateCha
 		&i.Metadata,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.AmountRefunded,
+		&i.Captured,
+		&i.Refunded,
+		&i.Disputed,
+		&i.InvoiceID,
+		&i.SyncedAt,
+		&i.TenantID,
 	)
 	return i, err
 }
 
-const UpdateCustomer = `-- name: UpdateCustomer :one
-UPDATE customers
-SET email = $2, name = $3, phone = $4, description = $5, metadata = $6, updated_at = NOW()
-WHERE id = $1
-RETURNING id, email, name, phone, description, metadata, created_at, updated_at
+const CreateCompositeCharge = `-- name: CreateCompositeCharge :one
+INSERT INTO composite_charges (
+    id, tenant_id, amount, currency, refund_strategy
+) VALUES (
+    $1, $2, $3, $4, $5
+) RETURNING id, tenant_id, amount, currency, refund_strategy, created_at, updated_at
 `
 
-type UpdateCustomerParams struct {
+type CreateCompositeChargeParams struct {
+	ID             string `json:"id"`
+	TenantID       string `json:"tenant_id"`
+	Amount         int64  `json:"amount"`
+	Currency       string `json:"currency"`
+	RefundStrategy string `json:"refund_strategy"`
+}
+
+func (q *Queries) CreateCompositeCharge(ctx context.Context, db DBTX, arg CreateCompositeChargeParams) (CompositeCharge, error) {
+	row := db.QueryRowContext(ctx, CreateCompositeCharge,
+		arg.ID,
+		arg.TenantID,
+		arg.Amount,
+		arg.Currency,
+		arg.RefundStrategy,
+	)
+	var i CompositeCharge
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.Amount,
+		&i.Currency,
+		&i.RefundStrategy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const CreateCompositeChargeLeg = `-- name: CreateCompositeChargeLeg :one
+INSERT INTO composite_charge_legs (
+    composite_charge_id, charge_id, position, priority, amount
+) VALUES (
+    $1, $2, $3, $4, $5
+) RETURNING composite_charge_id, charge_id, position, priority, amount
+`
+
+type CreateCompositeChargeLegParams struct {
+	CompositeChargeID string `json:"composite_charge_id"`
+	ChargeID          string `json:"charge_id"`
+	Position          int32  `json:"position"`
+	Priority          int32  `json:"priority"`
+	Amount            int64  `json:"amount"`
+}
+
+func (q *Queries) CreateCompositeChargeLeg(ctx context.Context, db DBTX, arg CreateCompositeChargeLegParams) (CompositeChargeLeg, error) {
+	row := db.QueryRowContext(ctx, CreateCompositeChargeLeg,
+		arg.CompositeChargeID,
+		arg.ChargeID,
+		arg.Position,
+		arg.Priority,
+		arg.Amount,
+	)
+	var i CompositeChargeLeg
+	err := row.Scan(
+		&i.CompositeChargeID,
+		&i.ChargeID,
+		&i.Position,
+		&i.Priority,
+		&i.Amount,
+	)
+	return i, err
+}
+
+const CreateCompositeRefund = `-- name: CreateCompositeRefund :one
+INSERT INTO composite_refunds (
+    id, composite_charge_id, tenant_id, amount, currency, reason, status
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+) RETURNING id, composite_charge_id, tenant_id, amount, currency, reason, status, created_at, updated_at
+`
+
+type CreateCompositeRefundParams struct {
+	ID                string `json:"id"`
+	CompositeChargeID string `json:"composite_charge_id"`
+	TenantID          string `json:"tenant_id"`
+	Amount            int64  `json:"amount"`
+	Currency          string `json:"currency"`
+	Reason            string `json:"reason"`
+	Status            string `json:"status"`
+}
+
+func (q *Queries) CreateCompositeRefund(ctx context.Context, db DBTX, arg CreateCompositeRefundParams) (CompositeRefund, error) {
+	row := db.QueryRowContext(ctx, CreateCompositeRefund,
+		arg.ID,
+		arg.CompositeChargeID,
+		arg.TenantID,
+		arg.Amount,
+		arg.Currency,
+		arg.Reason,
+		arg.Status,
+	)
+	var i CompositeRefund
+	err := row.Scan(
+		&i.ID,
+		&i.CompositeChargeID,
+		&i.TenantID,
+		&i.Amount,
+		&i.Currency,
+		&i.Reason,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const CreateCompositeRefundLeg = `-- name: CreateCompositeRefundLeg :one
+INSERT INTO composite_refund_legs (
+    composite_refund_id, charge_id, position, amount, status
+) VALUES (
+    $1, $2, $3, $4, $5
+) RETURNING composite_refund_id, charge_id, position, amount, refund_id, approval_id, status, failure_reason, created_at, updated_at
+`
+
+type CreateCompositeRefundLegParams struct {
+	CompositeRefundID string `json:"composite_refund_id"`
+	ChargeID          string `json:"charge_id"`
+	Position          int32  `json:"position"`
+	Amount            int64  `json:"amount"`
+	Status            string `json:"status"`
+}
+
+func (q *Queries) CreateCompositeRefundLeg(ctx context.Context, db DBTX, arg CreateCompositeRefundLegParams) (CompositeRefundLeg, error) {
+	row := db.QueryRowContext(ctx, CreateCompositeRefundLeg,
+		arg.CompositeRefundID,
+		arg.ChargeID,
+		arg.Position,
+		arg.Amount,
+		arg.Status,
+	)
+	var i CompositeRefundLeg
+	err := row.Scan(
+		&i.CompositeRefundID,
+		&i.ChargeID,
+		&i.Position,
+		&i.Amount,
+		&i.RefundID,
+		&i.ApprovalID,
+		&i.Status,
+		&i.FailureReason,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const CreateCustomer = `-- name: CreateCustomer :one
+INSERT INTO customers (
+    id, email, name, phone, description, metadata
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+) RETURNING id, email, name, phone, description, metadata, created_at, updated_at, synced_at, tenant_id
+`
+
+type CreateCustomerParams struct {
 	ID          string                `json:"id"`
 	Email       string                `json:"email"`
 	Name        string                `json:"name"`
@@ -712,8 +1081,8 @@ type UpdateCustomerParams struct {
 	Metadata    pqtype.NullRawMessage `json:"metadata"`
 }
 
-func (q *Queries) UpdateCustomer(ctx context.Context, db DBTX, arg UpdateCustomerParams) (Customer, error) {
-	row := db.QueryRowContext(ctx, UpdateCustomer,
+func (q *Queries) CreateCustomer(ctx context.Context, db DBTX, arg CreateCustomerParams) (Customer, error) {
+	row := db.QueryRowContext(ctx, CreateCustomer,
 		arg.ID,
 		arg.Email,
 		arg.Name,
@@ -731,35 +1100,10978 @@ func (q *Queries) UpdateCustomer(ctx context.Context, db DBTX, arg UpdateCustome
 		&i.Metadata,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.SyncedAt,
+		&i.TenantID,
 	)
 	return i, err
 }
 
-const UpdateRefundStatus = `-- name: UpdateRefundStatus :one
-UPDATE refunds
-SET status = $2, updated_at = NOW()
-WHERE id = $1
-RETURNING id, charge_id, amount, currency, status, reason, metadata, created_at, updated_at
+const CreateCustomerBalanceTransaction = `-- name: CreateCustomerBalanceTransaction :one
+INSERT INTO customer_balance_transactions (
+    id, tenant_id, customer_id, type, reason, amount, currency, balance_after,
+    description, source_id, provider_transaction_id, created_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+)
+RETURNING id, tenant_id, customer_id, type, reason, amount, currency, balance_after, description, source_id, provider_transaction_id, created_by, created_at
 `
 
-type UpdateRefundStatusParams struct {
-	ID     string `json:"id"`
-	Status string `json:"status"`
+type CreateCustomerBalanceTransactionParams struct {
+	ID                    string `json:"id"`
+	TenantID              string `json:"tenant_id"`
+	CustomerID            string `json:"customer_id"`
+	Type                  string `json:"type"`
+	Reason                string `json:"reason"`
+	Amount                int64  `json:"amount"`
+	Currency              string `json:"currency"`
+	BalanceAfter          int64  `json:"balance_after"`
+	Description           string `json:"description"`
+	SourceID              string `json:"source_id"`
+	ProviderTransactionID string `json:"provider_transaction_id"`
+	CreatedBy             string `json:"created_by"`
 }
 
-func (q *Queries) UpdateRefundStatus(ctx context.Context, db DBTX, arg UpdateRefundStatusParams) (Refund, error) {
-	row := db.QueryRowContext(ctx, UpdateRefundStatus, arg.ID, arg.Status)
-	var i Refund
-	err := row.Scan(
-		&i.ID,
-		&i.ChargeID,
+func (q *Queries) CreateCustomerBalanceTransaction(ctx context.Context, db DBTX, arg CreateCustomerBalanceTransactionParams) (CustomerBalanceTransaction, error) {
+	row := db.QueryRowContext(ctx, CreateCustomerBalanceTransaction,
+		arg.ID,
+		arg.TenantID,
+		arg.CustomerID,
+		arg.Type,
+		arg.Reason,
+		arg.Amount,
+		arg.Currency,
+		arg.BalanceAfter,
+		arg.Description,
+		arg.SourceID,
+		arg.ProviderTransactionID,
+		arg.CreatedBy,
+	)
+	var i CustomerBalanceTransaction
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.CustomerID,
+		&i.Type,
+		&i.Reason,
 		&i.Amount,
 		&i.Currency,
+		&i.BalanceAfter,
+		&i.Description,
+		&i.SourceID,
+		&i.ProviderTransactionID,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const CreateCustomerDeletion = `-- name: CreateCustomerDeletion :one
+INSERT INTO customer_deletions (
+    customer_id, tenant_id, deleted_at, purge_after
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (customer_id) DO UPDATE
+SET customer_id = EXCLUDED.customer_id
+RETURNING customer_id, tenant_id, deleted_at, purge_after, purged_at, created_at
+`
+
+type CreateCustomerDeletionParams struct {
+	CustomerID string    `json:"customer_id"`
+	TenantID   string    `json:"tenant_id"`
+	DeletedAt  time.Time `json:"deleted_at"`
+	PurgeAfter time.Time `json:"purge_after"`
+}
+
+func (q *Queries) CreateCustomerDeletion(ctx context.Context, db DBTX, arg CreateCustomerDeletionParams) (CustomerDeletion, error) {
+	row := db.QueryRowContext(ctx, CreateCustomerDeletion,
+		arg.CustomerID,
+		arg.TenantID,
+		arg.DeletedAt,
+		arg.PurgeAfter,
+	)
+	var i CustomerDeletion
+	err := row.Scan(
+		&i.CustomerID,
+		&i.TenantID,
+		&i.DeletedAt,
+		&i.PurgeAfter,
+		&i.PurgedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const CreateCustomerHold = `-- name: CreateCustomerHold :one
+INSERT INTO customer_holds (
+    id, tenant_id, customer_id, reason, source_id, status, blocks_charges, paused_subscriptions
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING id, tenant_id, customer_id, reason, source_id, status, blocks_charges, paused_subscriptions, release_reason, created_at, updated_at, released_at
+`
+
+type CreateCustomerHoldParams struct {
+	ID                  string                `json:"id"`
+	TenantID            string                `json:"tenant_id"`
+	CustomerID          string                `json:"customer_id"`
+	Reason              string                `json:"reason"`
+	SourceID            string                `json:"source_id"`
+	Status              string                `json:"status"`
+	BlocksCharges       bool                  `json:"blocks_charges"`
+	PausedSubscriptions pqtype.NullRawMessage `json:"paused_subscriptions"`
+}
+
+func (q *Queries) CreateCustomerHold(ctx context.Context, db DBTX, arg CreateCustomerHoldParams) (CustomerHold, error) {
+	row := db.QueryRowContext(ctx, CreateCustomerHold,
+		arg.ID,
+		arg.TenantID,
+		arg.CustomerID,
+		arg.Reason,
+		arg.SourceID,
+		arg.Status,
+		arg.BlocksCharges,
+		arg.PausedSubscriptions,
+	)
+	var i CustomerHold
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.CustomerID,
+		&i.Reason,
+		&i.SourceID,
+		&i.Status,
+		&i.BlocksCharges,
+		&i.PausedSubscriptions,
+		&i.ReleaseReason,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ReleasedAt,
+	)
+	return i, err
+}
+
+const CreateCustomerIdentity = `-- name: CreateCustomerIdentity :one
+INSERT INTO customer_identities (
+    customer_id, tenant_id, normalized_email, name
+) VALUES (
+    $1, $2, $3, $4
+)
+RETURNING customer_id, tenant_id, normalized_email, name, email_verified_at, verification_token_hash, verification_expires_at, created_at, updated_at
+`
+
+type CreateCustomerIdentityParams struct {
+	CustomerID      string `json:"customer_id"`
+	TenantID        string `json:"tenant_id"`
+	NormalizedEmail string `json:"normalized_email"`
+	Name            string `json:"name"`
+}
+
+func (q *Queries) CreateCustomerIdentity(ctx context.Context, db DBTX, arg CreateCustomerIdentityParams) (CustomerIdentity, error) {
+	row := db.QueryRowContext(ctx, CreateCustomerIdentity,
+		arg.CustomerID,
+		arg.TenantID,
+		arg.NormalizedEmail,
+		arg.Name,
+	)
+	var i CustomerIdentity
+	err := row.Scan(
+		&i.CustomerID,
+		&i.TenantID,
+		&i.NormalizedEmail,
+		&i.Name,
+		&i.EmailVerifiedAt,
+		&i.VerificationTokenHash,
+		&i.VerificationExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const CreateCustomerReference = `-- name: CreateCustomerReference :one
+INSERT INTO customer_references (
+    tenant_id, external_reference, customer_id
+) VALUES (
+    $1, $2, $3
+)
+ON CONFLICT (tenant_id, external_reference) DO NOTHING
+RETURNING tenant_id, external_reference, customer_id, created_at
+`
+
+type CreateCustomerReferenceParams struct {
+	TenantID          string `json:"tenant_id"`
+	ExternalReference string `json:"external_reference"`
+	CustomerID        string `json:"customer_id"`
+}
+
+func (q *Queries) CreateCustomerReference(ctx context.Context, db DBTX, arg CreateCustomerReferenceParams) (CustomerReference, error) {
+	row := db.QueryRowContext(ctx, CreateCustomerReference, arg.TenantID, arg.ExternalReference, arg.CustomerID)
+	var i CustomerReference
+	err := row.Scan(
+		&i.TenantID,
+		&i.ExternalReference,
+		&i.CustomerID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const CreateDeadLetter = `-- name: CreateDeadLetter :one
+INSERT INTO dlq_events (
+    id, source, dedupe_key, payload, headers, last_error, next_attempt_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+ON CONFLICT (source, dedupe_key) DO UPDATE
+SET last_error = EXCLUDED.last_error
+RETURNING id, source, dedupe_key, payload, headers, status, attempts, last_error, next_attempt_at, resolved_at, created_at, updated_at
+`
+
+type CreateDeadLetterParams struct {
+	ID            string          `json:"id"`
+	Source        string          `json:"source"`
+	DedupeKey     string          `json:"dedupe_key"`
+	Payload       []byte          `json:"payload"`
+	Headers       json.RawMessage `json:"headers"`
+	LastError     string          `json:"last_error"`
+	NextAttemptAt sql.NullTime    `json:"next_attempt_at"`
+}
+
+func (q *Queries) CreateDeadLetter(ctx context.Context, db DBTX, arg CreateDeadLetterParams) (DlqEvent, error) {
+	row := db.QueryRowContext(ctx, CreateDeadLetter,
+		arg.ID,
+		arg.Source,
+		arg.DedupeKey,
+		arg.Payload,
+		arg.Headers,
+		arg.LastError,
+		arg.NextAttemptAt,
+	)
+	var i DlqEvent
+	err := row.Scan(
+		&i.ID,
+		&i.Source,
+		&i.DedupeKey,
+		&i.Payload,
+		&i.Headers,
 		&i.Status,
+		&i.Attempts,
+		&i.LastError,
+		&i.NextAttemptAt,
+		&i.ResolvedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const CreateDisputeEvidenceFile = `-- name: CreateDisputeEvidenceFile :one
+INSERT INTO dispute_evidence_files (
+    id, dispute_id, provider_file_id, field, filename, content_type, size, uploaded_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING id, dispute_id, provider_file_id, field, filename, content_type, size, uploaded_by, created_at
+`
+
+type CreateDisputeEvidenceFileParams struct {
+	ID             string `json:"id"`
+	DisputeID      string `json:"dispute_id"`
+	ProviderFileID string `json:"provider_file_id"`
+	Field          string `json:"field"`
+	Filename       string `json:"filename"`
+	ContentType    string `json:"content_type"`
+	Size           int64  `json:"size"`
+	UploadedBy     string `json:"uploaded_by"`
+}
+
+func (q *Queries) CreateDisputeEvidenceFile(ctx context.Context, db DBTX, arg CreateDisputeEvidenceFileParams) (DisputeEvidenceFile, error) {
+	row := db.QueryRowContext(ctx, CreateDisputeEvidenceFile,
+		arg.ID,
+		arg.DisputeID,
+		arg.ProviderFileID,
+		arg.Field,
+		arg.Filename,
+		arg.ContentType,
+		arg.Size,
+		arg.UploadedBy,
+	)
+	var i DisputeEvidenceFile
+	err := row.Scan(
+		&i.ID,
+		&i.DisputeID,
+		&i.ProviderFileID,
+		&i.Field,
+		&i.Filename,
+		&i.ContentType,
+		&i.Size,
+		&i.UploadedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const CreateEphemeralKey = `-- name: CreateEphemeralKey :one
+INSERT INTO ephemeral_keys (
+    id, customer_id, secret_hash, scopes, issued_by, expires_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+RETURNING id, customer_id, secret_hash, scopes, issued_by, expires_at, revoked_at, created_at
+`
+
+type CreateEphemeralKeyParams struct {
+	ID         string          `json:"id"`
+	CustomerID string          `json:"customer_id"`
+	SecretHash string          `json:"secret_hash"`
+	Scopes     json.RawMessage `json:"scopes"`
+	IssuedBy   string          `json:"issued_by"`
+	ExpiresAt  time.Time       `json:"expires_at"`
+}
+
+func (q *Queries) CreateEphemeralKey(ctx context.Context, db DBTX, arg CreateEphemeralKeyParams) (EphemeralKey, error) {
+	row := db.QueryRowContext(ctx, CreateEphemeralKey,
+		arg.ID,
+		arg.CustomerID,
+		arg.SecretHash,
+		arg.Scopes,
+		arg.IssuedBy,
+		arg.ExpiresAt,
+	)
+	var i EphemeralKey
+	err := row.Scan(
+		&i.ID,
+		&i.CustomerID,
+		&i.SecretHash,
+		&i.Scopes,
+		&i.IssuedBy,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const CreateFraudListEntry = `-- name: CreateFraudListEntry :one
+INSERT INTO fraud_list_entries (
+    id, list, entry_type, value, reason, created_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+RETURNING id, list, entry_type, value, reason, created_by, created_at
+`
+
+type CreateFraudListEntryParams struct {
+	ID        string `json:"id"`
+	List      string `json:"list"`
+	EntryType string `json:"entry_type"`
+	Value     string `json:"value"`
+	Reason    string `json:"reason"`
+	CreatedBy string `json:"created_by"`
+}
+
+func (q *Queries) CreateFraudListEntry(ctx context.Context, db DBTX, arg CreateFraudListEntryParams) (FraudListEntry, error) {
+	row := db.QueryRowContext(ctx, CreateFraudListEntry,
+		arg.ID,
+		arg.List,
+		arg.EntryType,
+		arg.Value,
+		arg.Reason,
+		arg.CreatedBy,
+	)
+	var i FraudListEntry
+	err := row.Scan(
+		&i.ID,
+		&i.List,
+		&i.EntryType,
+		&i.Value,
 		&i.Reason,
-		&i.Metadata,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const CreateFraudReview = `-- name: CreateFraudReview :one
+INSERT INTO fraud_reviews (
+    id, tenant_id, screening_id, customer_id, amount, currency, request, findings
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING id, tenant_id, screening_id, customer_id, amount, currency, request, findings, status, decided_by, charge_id, failure_reason, decided_at, created_at, updated_at
+`
+
+type CreateFraudReviewParams struct {
+	ID          string          `json:"id"`
+	TenantID    string          `json:"tenant_id"`
+	ScreeningID string          `json:"screening_id"`
+	CustomerID  string          `json:"customer_id"`
+	Amount      int64           `json:"amount"`
+	Currency    string          `json:"currency"`
+	Request     json.RawMessage `json:"request"`
+	Findings    json.RawMessage `json:"findings"`
+}
+
+func (q *Queries) CreateFraudReview(ctx context.Context, db DBTX, arg CreateFraudReviewParams) (FraudReview, error) {
+	row := db.QueryRowContext(ctx, CreateFraudReview,
+		arg.ID,
+		arg.TenantID,
+		arg.ScreeningID,
+		arg.CustomerID,
+		arg.Amount,
+		arg.Currency,
+		arg.Request,
+		arg.Findings,
+	)
+	var i FraudReview
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.ScreeningID,
+		&i.CustomerID,
+		&i.Amount,
+		&i.Currency,
+		&i.Request,
+		&i.Findings,
+		&i.Status,
+		&i.DecidedBy,
+		&i.ChargeID,
+		&i.FailureReason,
+		&i.DecidedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const CreateHeldMutation = `-- name: CreateHeldMutation :one
+INSERT INTO held_mutations (
+    id, quarantine_id, tenant_id, api_key_id, operator_id, method, path, content_type, body, status
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+) RETURNING id, quarantine_id, tenant_id, api_key_id, operator_id, method, path, content_type, body, status, decided_by, decided_at, response_status, response_body, created_at, updated_at
+`
+
+type CreateHeldMutationParams struct {
+	ID           string `json:"id"`
+	QuarantineID string `json:"quarantine_id"`
+	TenantID     string `json:"tenant_id"`
+	ApiKeyID     string `json:"api_key_id"`
+	OperatorID   string `json:"operator_id"`
+	Method       string `json:"method"`
+	Path         string `json:"path"`
+	ContentType  string `json:"content_type"`
+	Body         string `json:"body"`
+	Status       string `json:"status"`
+}
+
+func (q *Queries) CreateHeldMutation(ctx context.Context, db DBTX, arg CreateHeldMutationParams) (HeldMutation, error) {
+	row := db.QueryRowContext(ctx, CreateHeldMutation,
+		arg.ID,
+		arg.QuarantineID,
+		arg.TenantID,
+		arg.ApiKeyID,
+		arg.OperatorID,
+		arg.Method,
+		arg.Path,
+		arg.ContentType,
+		arg.Body,
+		arg.Status,
+	)
+	var i HeldMutation
+	err := row.Scan(
+		&i.ID,
+		&i.QuarantineID,
+		&i.TenantID,
+		&i.ApiKeyID,
+		&i.OperatorID,
+		&i.Method,
+		&i.Path,
+		&i.ContentType,
+		&i.Body,
+		&i.Status,
+		&i.DecidedBy,
+		&i.DecidedAt,
+		&i.ResponseStatus,
+		&i.ResponseBody,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
 	return i, err
 }
+
+const CreateJobRun = `-- name: CreateJobRun :one
+INSERT INTO job_runs (
+    id, job_name, status, triggered_by, attempts, instance, started_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING id, job_name, status, triggered_by, attempts, error, instance, started_at, finished_at, created_at
+`
+
+type CreateJobRunParams struct {
+	ID          string    `json:"id"`
+	JobName     string    `json:"job_name"`
+	Status      string    `json:"status"`
+	TriggeredBy string    `json:"triggered_by"`
+	Attempts    int32     `json:"attempts"`
+	Instance    string    `json:"instance"`
+	StartedAt   time.Time `json:"started_at"`
+}
+
+func (q *Queries) CreateJobRun(ctx context.Context, db DBTX, arg CreateJobRunParams) (JobRun, error) {
+	row := db.QueryRowContext(ctx, CreateJobRun,
+		arg.ID,
+		arg.JobName,
+		arg.Status,
+		arg.TriggeredBy,
+		arg.Attempts,
+		arg.Instance,
+		arg.StartedAt,
+	)
+	var i JobRun
+	err := row.Scan(
+		&i.ID,
+		&i.JobName,
+		&i.Status,
+		&i.TriggeredBy,
+		&i.Attempts,
+		&i.Error,
+		&i.Instance,
+		&i.StartedAt,
+		&i.FinishedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const CreateLedgerEntry = `-- name: CreateLedgerEntry :exec
+INSERT INTO ledger_entries (
+    id, debit_account, credit_account, amount, currency, reference_type, reference_id, description
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+ON CONFLICT (reference_type, reference_id, debit_account, credit_account) DO NOTHING
+`
+
+type CreateLedgerEntryParams struct {
+	ID            string `json:"id"`
+	DebitAccount  string `json:"debit_account"`
+	CreditAccount string `json:"credit_account"`
+	Amount        int64  `json:"amount"`
+	Currency      string `json:"currency"`
+	ReferenceType string `json:"reference_type"`
+	ReferenceID   string `json:"reference_id"`
+	Description   string `json:"description"`
+}
+
+func (q *Queries) CreateLedgerEntry(ctx context.Context, db DBTX, arg CreateLedgerEntryParams) error {
+	_, err := db.ExecContext(ctx, CreateLedgerEntry,
+		arg.ID,
+		arg.DebitAccount,
+		arg.CreditAccount,
+		arg.Amount,
+		arg.Currency,
+		arg.ReferenceType,
+		arg.ReferenceID,
+		arg.Description,
+	)
+	return err
+}
+
+const CreateOffboardingExport = `-- name: CreateOffboardingExport :one
+INSERT INTO offboarding_exports (
+    id, tenant_id, recipient_public_key
+) VALUES (
+    $1, $2, $3
+)
+RETURNING id, tenant_id, status, recipient_public_key, manifest, archive_sha256, archive_size, error, pan_migration_status, pan_migration_destination, pan_migration_reference, pan_migration_requested_at, started_at, completed_at, created_at, updated_at
+`
+
+type CreateOffboardingExportParams struct {
+	ID                 string `json:"id"`
+	TenantID           string `json:"tenant_id"`
+	RecipientPublicKey string `json:"recipient_public_key"`
+}
+
+func (q *Queries) CreateOffboardingExport(ctx context.Context, db DBTX, arg CreateOffboardingExportParams) (OffboardingExport, error) {
+	row := db.QueryRowContext(ctx, CreateOffboardingExport, arg.ID, arg.TenantID, arg.RecipientPublicKey)
+	var i OffboardingExport
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.Status,
+		&i.RecipientPublicKey,
+		&i.Manifest,
+		&i.ArchiveSha256,
+		&i.ArchiveSize,
+		&i.Error,
+		&i.PanMigrationStatus,
+		&i.PanMigrationDestination,
+		&i.PanMigrationReference,
+		&i.PanMigrationRequestedAt,
+		&i.StartedAt,
+		&i.CompletedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const CreatePaymentLink = `-- name: CreatePaymentLink :one
+INSERT INTO payment_links (
+    id, url, amount, currency, product_name, price_id, expires_at, max_uses, metadata, tenant_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+)
+RETURNING id, url, amount, currency, product_name, price_id, active, expires_at, max_uses, conversions, amount_collected, metadata, deactivated_at, created_at, updated_at, tenant_id
+`
+
+type CreatePaymentLinkParams struct {
+	ID          string          `json:"id"`
+	Url         string          `json:"url"`
+	Amount      int64           `json:"amount"`
+	Currency    string          `json:"currency"`
+	ProductName string          `json:"product_name"`
+	PriceID     string          `json:"price_id"`
+	ExpiresAt   sql.NullTime    `json:"expires_at"`
+	MaxUses     int64           `json:"max_uses"`
+	Metadata    json.RawMessage `json:"metadata"`
+	TenantID    string          `json:"tenant_id"`
+}
+
+func (q *Queries) CreatePaymentLink(ctx context.Context, db DBTX, arg CreatePaymentLinkParams) (PaymentLink, error) {
+	row := db.QueryRowContext(ctx, CreatePaymentLink,
+		arg.ID,
+		arg.Url,
+		arg.Amount,
+		arg.Currency,
+		arg.ProductName,
+		arg.PriceID,
+		arg.ExpiresAt,
+		arg.MaxUses,
+		arg.Metadata,
+		arg.TenantID,
+	)
+	var i PaymentLink
+	err := row.Scan(
+		&i.ID,
+		&i.Url,
+		&i.Amount,
+		&i.Currency,
+		&i.ProductName,
+		&i.PriceID,
+		&i.Active,
+		&i.ExpiresAt,
+		&i.MaxUses,
+		&i.Conversions,
+		&i.AmountCollected,
+		&i.Metadata,
+		&i.DeactivatedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const CreatePaymentMethod = `-- name: CreatePaymentMethod :one
+INSERT INTO payment_methods (
+    id, type, customer_id, card_last4, card_brand, card_exp_month, card_exp_year, card_fingerprint, metadata
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+) RETURNING id, type, customer_id, card_last4, card_brand, card_exp_month, card_exp_year, card_fingerprint, metadata, created_at, synced_at, tenant_id
+`
+
+type CreatePaymentMethodParams struct {
+	ID              string                `json:"id"`
+	Type            string                `json:"type"`
+	CustomerID      string                `json:"customer_id"`
+	CardLast4       sql.NullString        `json:"card_last4"`
+	CardBrand       sql.NullString        `json:"card_brand"`
+	CardExpMonth    sql.NullInt32         `json:"card_exp_month"`
+	CardExpYear     sql.NullInt32         `json:"card_exp_year"`
+	CardFingerprint sql.NullString        `json:"card_fingerprint"`
+	Metadata        pqtype.NullRawMessage `json:"metadata"`
+}
+
+func (q *Queries) CreatePaymentMethod(ctx context.Context, db DBTX, arg CreatePaymentMethodParams) (PaymentMethod, error) {
+	row := db.QueryRowContext(ctx, CreatePaymentMethod,
+		arg.ID,
+		arg.Type,
+		arg.CustomerID,
+		arg.CardLast4,
+		arg.CardBrand,
+		arg.CardExpMonth,
+		arg.CardExpYear,
+		arg.CardFingerprint,
+		arg.Metadata,
+	)
+	var i PaymentMethod
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.CustomerID,
+		&i.CardLast4,
+		&i.CardBrand,
+		&i.CardExpMonth,
+		&i.CardExpYear,
+		&i.CardFingerprint,
+		&i.Metadata,
+		&i.CreatedAt,
+		&i.SyncedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const CreateProviderCredentialAudit = `-- name: CreateProviderCredentialAudit :exec
+INSERT INTO provider_credential_audit (
+    id, tenant_id, provider, action, version, changed_fields, api_key_id, operator_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+`
+
+type CreateProviderCredentialAuditParams struct {
+	ID            string          `json:"id"`
+	TenantID      string          `json:"tenant_id"`
+	Provider      string          `json:"provider"`
+	Action        string          `json:"action"`
+	Version       int32           `json:"version"`
+	ChangedFields json.RawMessage `json:"changed_fields"`
+	ApiKeyID      string          `json:"api_key_id"`
+	OperatorID    string          `json:"operator_id"`
+}
+
+func (q *Queries) CreateProviderCredentialAudit(ctx context.Context, db DBTX, arg CreateProviderCredentialAuditParams) error {
+	_, err := db.ExecContext(ctx, CreateProviderCredentialAudit,
+		arg.ID,
+		arg.TenantID,
+		arg.Provider,
+		arg.Action,
+		arg.Version,
+		arg.ChangedFields,
+		arg.ApiKeyID,
+		arg.OperatorID,
+	)
+	return err
+}
+
+const CreateQuarantine = `-- name: CreateQuarantine :one
+INSERT INTO quarantines (
+    id, subject_type, subject_id, reason, created_by
+) VALUES (
+    $1, $2, $3, $4, $5
+) RETURNING id, subject_type, subject_id, reason, created_by, lifted_by, lifted_at, created_at, updated_at
+`
+
+type CreateQuarantineParams struct {
+	ID          string `json:"id"`
+	SubjectType string `json:"subject_type"`
+	SubjectID   string `json:"subject_id"`
+	Reason      string `json:"reason"`
+	CreatedBy   string `json:"created_by"`
+}
+
+func (q *Queries) CreateQuarantine(ctx context.Context, db DBTX, arg CreateQuarantineParams) (Quarantine, error) {
+	row := db.QueryRowContext(ctx, CreateQuarantine,
+		arg.ID,
+		arg.SubjectType,
+		arg.SubjectID,
+		arg.Reason,
+		arg.CreatedBy,
+	)
+	var i Quarantine
+	err := row.Scan(
+		&i.ID,
+		&i.SubjectType,
+		&i.SubjectID,
+		&i.Reason,
+		&i.CreatedBy,
+		&i.LiftedBy,
+		&i.LiftedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const CreateReconciliationMismatch = `-- name: CreateReconciliationMismatch :exec
+INSERT INTO reconciliation_mismatches (
+    id, run_id, kind, object_type, object_id, balance_transaction_id, provider_amount, local_amount, currency, detail
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+)
+`
+
+type CreateReconciliationMismatchParams struct {
+	ID                   string `json:"id"`
+	RunID                string `json:"run_id"`
+	Kind                 string `json:"kind"`
+	ObjectType           string `json:"object_type"`
+	ObjectID             string `json:"object_id"`
+	BalanceTransactionID string `json:"balance_transaction_id"`
+	ProviderAmount       int64  `json:"provider_amount"`
+	LocalAmount          int64  `json:"local_amount"`
+	Currency             string `json:"currency"`
+	Detail               string `json:"detail"`
+}
+
+func (q *Queries) CreateReconciliationMismatch(ctx context.Context, db DBTX, arg CreateReconciliationMismatchParams) error {
+	_, err := db.ExecContext(ctx, CreateReconciliationMismatch,
+		arg.ID,
+		arg.RunID,
+		arg.Kind,
+		arg.ObjectType,
+		arg.ObjectID,
+		arg.BalanceTransactionID,
+		arg.ProviderAmount,
+		arg.LocalAmount,
+		arg.Currency,
+		arg.Detail,
+	)
+	return err
+}
+
+const CreateReconciliationRun = `-- name: CreateReconciliationRun :one
+INSERT INTO reconciliation_runs (
+    id, status, period_start, period_end, started_at
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+RETURNING id, status, period_start, period_end, transactions_checked, payouts_checked, matched, mismatches, error, started_at, completed_at
+`
+
+type CreateReconciliationRunParams struct {
+	ID          string    `json:"id"`
+	Status      string    `json:"status"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	StartedAt   time.Time `json:"started_at"`
+}
+
+func (q *Queries) CreateReconciliationRun(ctx context.Context, db DBTX, arg CreateReconciliationRunParams) (ReconciliationRun, error) {
+	row := db.QueryRowContext(ctx, CreateReconciliationRun,
+		arg.ID,
+		arg.Status,
+		arg.PeriodStart,
+		arg.PeriodEnd,
+		arg.StartedAt,
+	)
+	var i ReconciliationRun
+	err := row.Scan(
+		&i.ID,
+		&i.Status,
+		&i.PeriodStart,
+		&i.PeriodEnd,
+		&i.TransactionsChecked,
+		&i.PayoutsChecked,
+		&i.Matched,
+		&i.Mismatches,
+		&i.Error,
+		&i.StartedAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
+
+const CreateRefund = `-- name: CreateRefund :one
+INSERT INTO refunds (
+    id, charge_id, amount, currency, status, reason, metadata
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+) RETURNING id, charge_id, amount, currency, status, reason, metadata, created_at, updated_at, synced_at, tenant_id
+`
+
+type CreateRefundParams struct {
+	ID       string                `json:"id"`
+	ChargeID string                `json:"charge_id"`
+	Amount   int64                 `json:"amount"`
+	Currency string                `json:"currency"`
+	Status   string                `json:"status"`
+	Reason   sql.NullString        `json:"reason"`
+	Metadata pqtype.NullRawMessage `json:"metadata"`
+}
+
+func (q *Queries) CreateRefund(ctx context.Context, db DBTX, arg CreateRefundParams) (Refund, error) {
+	row := db.QueryRowContext(ctx, CreateRefund,
+		arg.ID,
+		arg.ChargeID,
+		arg.Amount,
+		arg.Currency,
+		arg.Status,
+		arg.Reason,
+		arg.Metadata,
+	)
+	var i Refund
+	err := row.Scan(
+		&i.ID,
+		&i.ChargeID,
+		&i.Amount,
+		&i.Currency,
+		&i.Status,
+		&i.Reason,
+		&i.Metadata,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SyncedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const CreateRefundApproval = `-- name: CreateRefundApproval :one
+INSERT INTO refund_approvals (
+    id, tenant_id, api_key_id, operator_id, request, amount, reasons, status
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING id, tenant_id, api_key_id, operator_id, request, amount, reasons, status, decided_by, refund_id, created_at, updated_at, decided_at
+`
+
+type CreateRefundApprovalParams struct {
+	ID         string          `json:"id"`
+	TenantID   string          `json:"tenant_id"`
+	ApiKeyID   string          `json:"api_key_id"`
+	OperatorID string          `json:"operator_id"`
+	Request    json.RawMessage `json:"request"`
+	Amount     int64           `json:"amount"`
+	Reasons    json.RawMessage `json:"reasons"`
+	Status     string          `json:"status"`
+}
+
+func (q *Queries) CreateRefundApproval(ctx context.Context, db DBTX, arg CreateRefundApprovalParams) (RefundApproval, error) {
+	row := db.QueryRowContext(ctx, CreateRefundApproval,
+		arg.ID,
+		arg.TenantID,
+		arg.ApiKeyID,
+		arg.OperatorID,
+		arg.Request,
+		arg.Amount,
+		arg.Reasons,
+		arg.Status,
+	)
+	var i RefundApproval
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.ApiKeyID,
+		&i.OperatorID,
+		&i.Request,
+		&i.Amount,
+		&i.Reasons,
+		&i.Status,
+		&i.DecidedBy,
+		&i.RefundID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DecidedAt,
+	)
+	return i, err
+}
+
+const CreateRefundBatch = `-- name: CreateRefundBatch :one
+INSERT INTO refund_batches (
+    id, tenant_id, api_key_id, operator_id, requests, scheduled_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+RETURNING id, tenant_id, api_key_id, operator_id, requests, status, scheduled_at, started_at, completed_at, created_at, updated_at
+`
+
+type CreateRefundBatchParams struct {
+	ID          string          `json:"id"`
+	TenantID    string          `json:"tenant_id"`
+	ApiKeyID    string          `json:"api_key_id"`
+	OperatorID  string          `json:"operator_id"`
+	Requests    json.RawMessage `json:"requests"`
+	ScheduledAt time.Time       `json:"scheduled_at"`
+}
+
+func (q *Queries) CreateRefundBatch(ctx context.Context, db DBTX, arg CreateRefundBatchParams) (RefundBatch, error) {
+	row := db.QueryRowContext(ctx, CreateRefundBatch,
+		arg.ID,
+		arg.TenantID,
+		arg.ApiKeyID,
+		arg.OperatorID,
+		arg.Requests,
+		arg.ScheduledAt,
+	)
+	var i RefundBatch
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.ApiKeyID,
+		&i.OperatorID,
+		&i.Requests,
+		&i.Status,
+		&i.ScheduledAt,
+		&i.StartedAt,
+		&i.CompletedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const CreateSmartRoutingRule = `-- name: CreateSmartRoutingRule :one
+INSERT INTO smart_routing_rules (
+    id, currency, bin_country, min_amount, max_amount, strategy,
+    providers, pinned_provider, priority, enabled, description, updated_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+)
+RETURNING id, currency, bin_country, min_amount, max_amount, strategy, providers, pinned_provider, priority, enabled, description, updated_by, created_at, updated_at
+`
+
+type CreateSmartRoutingRuleParams struct {
+	ID             string          `json:"id"`
+	Currency       string          `json:"currency"`
+	BinCountry     string          `json:"bin_country"`
+	MinAmount      int64           `json:"min_amount"`
+	MaxAmount      int64           `json:"max_amount"`
+	Strategy       string          `json:"strategy"`
+	Providers      json.RawMessage `json:"providers"`
+	PinnedProvider string          `json:"pinned_provider"`
+	Priority       int32           `json:"priority"`
+	Enabled        bool            `json:"enabled"`
+	Description    string          `json:"description"`
+	UpdatedBy      string          `json:"updated_by"`
+}
+
+func (q *Queries) CreateSmartRoutingRule(ctx context.Context, db DBTX, arg CreateSmartRoutingRuleParams) (SmartRoutingRule, error) {
+	row := db.QueryRowContext(ctx, CreateSmartRoutingRule,
+		arg.ID,
+		arg.Currency,
+		arg.BinCountry,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.Strategy,
+		arg.Providers,
+		arg.PinnedProvider,
+		arg.Priority,
+		arg.Enabled,
+		arg.Description,
+		arg.UpdatedBy,
+	)
+	var i SmartRoutingRule
+	err := row.Scan(
+		&i.ID,
+		&i.Currency,
+		&i.BinCountry,
+		&i.MinAmount,
+		&i.MaxAmount,
+		&i.Strategy,
+		&i.Providers,
+		&i.PinnedProvider,
+		&i.Priority,
+		&i.Enabled,
+		&i.Description,
+		&i.UpdatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const CreateVaultToken = `-- name: CreateVaultToken :one
+INSERT INTO vault_tokens (
+    id, provider, provider_token, customer_id, type, fingerprint, tenant_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+ON CONFLICT (provider, provider_token) DO UPDATE
+SET customer_id = EXCLUDED.customer_id
+RETURNING id, provider, provider_token, customer_id, type, fingerprint, created_at, updated_at, tenant_id
+`
+
+type CreateVaultTokenParams struct {
+	ID            string `json:"id"`
+	Provider      string `json:"provider"`
+	ProviderToken string `json:"provider_token"`
+	CustomerID    string `json:"customer_id"`
+	Type          string `json:"type"`
+	Fingerprint   string `json:"fingerprint"`
+	TenantID      string `json:"tenant_id"`
+}
+
+func (q *Queries) CreateVaultToken(ctx context.Context, db DBTX, arg CreateVaultTokenParams) (VaultToken, error) {
+	row := db.QueryRowContext(ctx, CreateVaultToken,
+		arg.ID,
+		arg.Provider,
+		arg.ProviderToken,
+		arg.CustomerID,
+		arg.Type,
+		arg.Fingerprint,
+		arg.TenantID,
+	)
+	var i VaultToken
+	err := row.Scan(
+		&i.ID,
+		&i.Provider,
+		&i.ProviderToken,
+		&i.CustomerID,
+		&i.Type,
+		&i.Fingerprint,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const CreateWebhookDelivery = `-- name: CreateWebhookDelivery :one
+INSERT INTO webhook_deliveries (
+    id, tenant_id, endpoint_id, event_id, event_type, payload, next_attempt_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+ON CONFLICT (endpoint_id, event_id) DO UPDATE
+SET event_type = EXCLUDED.event_type
+RETURNING id, tenant_id, endpoint_id, event_id, event_type, payload, status, attempts, last_status_code, last_error, next_attempt_at, delivered_at, created_at, updated_at
+`
+
+type CreateWebhookDeliveryParams struct {
+	ID            string       `json:"id"`
+	TenantID      string       `json:"tenant_id"`
+	EndpointID    string       `json:"endpoint_id"`
+	EventID       string       `json:"event_id"`
+	EventType     string       `json:"event_type"`
+	Payload       []byte       `json:"payload"`
+	NextAttemptAt sql.NullTime `json:"next_attempt_at"`
+}
+
+func (q *Queries) CreateWebhookDelivery(ctx context.Context, db DBTX, arg CreateWebhookDeliveryParams) (WebhookDelivery, error) {
+	row := db.QueryRowContext(ctx, CreateWebhookDelivery,
+		arg.ID,
+		arg.TenantID,
+		arg.EndpointID,
+		arg.EventID,
+		arg.EventType,
+		arg.Payload,
+		arg.NextAttemptAt,
+	)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.EndpointID,
+		&i.EventID,
+		&i.EventType,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.LastStatusCode,
+		&i.LastError,
+		&i.NextAttemptAt,
+		&i.DeliveredAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const CreateWebhookDeliveryAttempt = `-- name: CreateWebhookDeliveryAttempt :one
+INSERT INTO webhook_delivery_attempts (
+    id, delivery_id, status_code, response_body, error, duration_ms, manual
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING id, delivery_id, status_code, response_body, error, duration_ms, manual, attempted_at
+`
+
+type CreateWebhookDeliveryAttemptParams struct {
+	ID           string `json:"id"`
+	DeliveryID   string `json:"delivery_id"`
+	StatusCode   int32  `json:"status_code"`
+	ResponseBody string `json:"response_body"`
+	Error        string `json:"error"`
+	DurationMs   int64  `json:"duration_ms"`
+	Manual       bool   `json:"manual"`
+}
+
+func (q *Queries) CreateWebhookDeliveryAttempt(ctx context.Context, db DBTX, arg CreateWebhookDeliveryAttemptParams) (WebhookDeliveryAttempt, error) {
+	row := db.QueryRowContext(ctx, CreateWebhookDeliveryAttempt,
+		arg.ID,
+		arg.DeliveryID,
+		arg.StatusCode,
+		arg.ResponseBody,
+		arg.Error,
+		arg.DurationMs,
+		arg.Manual,
+	)
+	var i WebhookDeliveryAttempt
+	err := row.Scan(
+		&i.ID,
+		&i.DeliveryID,
+		&i.StatusCode,
+		&i.ResponseBody,
+		&i.Error,
+		&i.DurationMs,
+		&i.Manual,
+		&i.AttemptedAt,
+	)
+	return i, err
+}
+
+const CreateWebhookEndpoint = `-- name: CreateWebhookEndpoint :one
+INSERT INTO webhook_endpoints (
+    id, tenant_id, url, description, event_types, secret_ciphertext, enabled
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING id, tenant_id, url, description, event_types, secret_ciphertext, enabled, created_at, updated_at
+`
+
+type CreateWebhookEndpointParams struct {
+	ID               string          `json:"id"`
+	TenantID         string          `json:"tenant_id"`
+	Url              string          `json:"url"`
+	Description      string          `json:"description"`
+	EventTypes       json.RawMessage `json:"event_types"`
+	SecretCiphertext []byte          `json:"secret_ciphertext"`
+	Enabled          bool            `json:"enabled"`
+}
+
+func (q *Queries) CreateWebhookEndpoint(ctx context.Context, db DBTX, arg CreateWebhookEndpointParams) (WebhookEndpoint, error) {
+	row := db.QueryRowContext(ctx, CreateWebhookEndpoint,
+		arg.ID,
+		arg.TenantID,
+		arg.Url,
+		arg.Description,
+		arg.EventTypes,
+		arg.SecretCiphertext,
+		arg.Enabled,
+	)
+	var i WebhookEndpoint
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.Url,
+		&i.Description,
+		&i.EventTypes,
+		&i.SecretCiphertext,
+		&i.Enabled,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const CreateWebhookSecretRotation = `-- name: CreateWebhookSecretRotation :one
+INSERT INTO webhook_secret_rotations (
+    id, old_endpoint_id, new_endpoint_id, new_secret, grace_ends_at
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+RETURNING id, status, old_endpoint_id, new_endpoint_id, new_secret, deliveries, confirmed_at, grace_ends_at, completed_at, cancelled_at, created_at, updated_at
+`
+
+type CreateWebhookSecretRotationParams struct {
+	ID            string    `json:"id"`
+	OldEndpointID string    `json:"old_endpoint_id"`
+	NewEndpointID string    `json:"new_endpoint_id"`
+	NewSecret     []byte    `json:"new_secret"`
+	GraceEndsAt   time.Time `json:"grace_ends_at"`
+}
+
+func (q *Queries) CreateWebhookSecretRotation(ctx context.Context, db DBTX, arg CreateWebhookSecretRotationParams) (WebhookSecretRotation, error) {
+	row := db.QueryRowContext(ctx, CreateWebhookSecretRotation,
+		arg.ID,
+		arg.OldEndpointID,
+		arg.NewEndpointID,
+		arg.NewSecret,
+		arg.GraceEndsAt,
+	)
+	var i WebhookSecretRotation
+	err := row.Scan(
+		&i.ID,
+		&i.Status,
+		&i.OldEndpointID,
+		&i.NewEndpointID,
+		&i.NewSecret,
+		&i.Deliveries,
+		&i.ConfirmedAt,
+		&i.GraceEndsAt,
+		&i.CompletedAt,
+		&i.CancelledAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const DeactivatePaymentLink = `-- name: DeactivatePaymentLink :one
+UPDATE payment_links
+SET active = FALSE, deactivated_at = $2
+WHERE id = $1 AND active AND ($3 = '' OR tenant_id = $3)
+RETURNING id, url, amount, currency, product_name, price_id, active, expires_at, max_uses, conversions, amount_collected, metadata, deactivated_at, created_at, updated_at, tenant_id
+`
+
+type DeactivatePaymentLinkParams struct {
+	ID            string       `json:"id"`
+	DeactivatedAt sql.NullTime `json:"deactivated_at"`
+	TenantID      string       `json:"tenant_id"`
+}
+
+func (q *Queries) DeactivatePaymentLink(ctx context.Context, db DBTX, arg DeactivatePaymentLinkParams) (PaymentLink, error) {
+	row := db.QueryRowContext(ctx, DeactivatePaymentLink, arg.ID, arg.DeactivatedAt, arg.TenantID)
+	var i PaymentLink
+	err := row.Scan(
+		&i.ID,
+		&i.Url,
+		&i.Amount,
+		&i.Currency,
+		&i.ProductName,
+		&i.PriceID,
+		&i.Active,
+		&i.ExpiresAt,
+		&i.MaxUses,
+		&i.Conversions,
+		&i.AmountCollected,
+		&i.Metadata,
+		&i.DeactivatedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const DecideFraudReview = `-- name: DecideFraudReview :one
+UPDATE fraud_reviews
+SET status = $2,
+    decided_by = $3,
+    decided_at = NOW()
+WHERE id = $1 AND status = 'pending'
+RETURNING id, tenant_id, screening_id, customer_id, amount, currency, request, findings, status, decided_by, charge_id, failure_reason, decided_at, created_at, updated_at
+`
+
+type DecideFraudReviewParams struct {
+	ID        string `json:"id"`
+	Status    string `json:"status"`
+	DecidedBy string `json:"decided_by"`
+}
+
+func (q *Queries) DecideFraudReview(ctx context.Context, db DBTX, arg DecideFraudReviewParams) (FraudReview, error) {
+	row := db.QueryRowContext(ctx, DecideFraudReview, arg.ID, arg.Status, arg.DecidedBy)
+	var i FraudReview
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.ScreeningID,
+		&i.CustomerID,
+		&i.Amount,
+		&i.Currency,
+		&i.Request,
+		&i.Findings,
+		&i.Status,
+		&i.DecidedBy,
+		&i.ChargeID,
+		&i.FailureReason,
+		&i.DecidedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const DecideHeldMutation = `-- name: DecideHeldMutation :one
+UPDATE held_mutations
+SET status = $2, decided_by = $3, decided_at = NOW()
+WHERE id = $1 AND status = 'pending'
+RETURNING id, quarantine_id, tenant_id, api_key_id, operator_id, method, path, content_type, body, status, decided_by, decided_at, response_status, response_body, created_at, updated_at
+`
+
+type DecideHeldMutationParams struct {
+	ID        string         `json:"id"`
+	Status    string         `json:"status"`
+	DecidedBy sql.NullString `json:"decided_by"`
+}
+
+func (q *Queries) DecideHeldMutation(ctx context.Context, db DBTX, arg DecideHeldMutationParams) (HeldMutation, error) {
+	row := db.QueryRowContext(ctx, DecideHeldMutation, arg.ID, arg.Status, arg.DecidedBy)
+	var i HeldMutation
+	err := row.Scan(
+		&i.ID,
+		&i.QuarantineID,
+		&i.TenantID,
+		&i.ApiKeyID,
+		&i.OperatorID,
+		&i.Method,
+		&i.Path,
+		&i.ContentType,
+		&i.Body,
+		&i.Status,
+		&i.DecidedBy,
+		&i.DecidedAt,
+		&i.ResponseStatus,
+		&i.ResponseBody,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const DecideRefundApproval = `-- name: DecideRefundApproval :one
+UPDATE refund_approvals
+SET status = $2, decided_by = $3, refund_id = $4, decided_at = NOW(), updated_at = NOW()
+WHERE id = $1
+  AND status = CASE WHEN $2 = 'approved' THEN 'approving' ELSE 'pending' END
+  AND ($5 = '' OR tenant_id = $5)
+RETURNING id, tenant_id, api_key_id, operator_id, request, amount, reasons, status, decided_by, refund_id, created_at, updated_at, decided_at
+`
+
+type DecideRefundApprovalParams struct {
+	ID        string         `json:"id"`
+	Status    string         `json:"status"`
+	DecidedBy sql.NullString `json:"decided_by"`
+	RefundID  sql.NullString `json:"refund_id"`
+	TenantID  string         `json:"tenant_id"`
+}
+
+func (q *Queries) DecideRefundApproval(ctx context.Context, db DBTX, arg DecideRefundApprovalParams) (RefundApproval, error) {
+	row := db.QueryRowContext(ctx, DecideRefundApproval,
+		arg.ID,
+		arg.Status,
+		arg.DecidedBy,
+		arg.RefundID,
+		arg.TenantID,
+	)
+	var i RefundApproval
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.ApiKeyID,
+		&i.OperatorID,
+		&i.Request,
+		&i.Amount,
+		&i.Reasons,
+		&i.Status,
+		&i.DecidedBy,
+		&i.RefundID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DecidedAt,
+	)
+	return i, err
+}
+
+const DeleteAutoRefundExclusion = `-- name: DeleteAutoRefundExclusion :execrows
+DELETE FROM auto_refund_exclusions
+WHERE customer_id = $1
+`
+
+func (q *Queries) DeleteAutoRefundExclusion(ctx context.Context, db DBTX, customerID string) (int64, error) {
+	result, err := db.ExecContext(ctx, DeleteAutoRefundExclusion, customerID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const DeleteBlocklistEntry = `-- name: DeleteBlocklistEntry :execrows
+DELETE FROM blocklist_entries
+WHERE id = $1
+`
+
+func (q *Queries) DeleteBlocklistEntry(ctx context.Context, db DBTX, id string) (int64, error) {
+	result, err := db.ExecContext(ctx, DeleteBlocklistEntry, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const DeleteCustomFieldDefinition = `-- name: DeleteCustomFieldDefinition :execrows
+DELETE FROM custom_field_definitions
+WHERE tenant_id = $1
+`
+
+func (q *Queries) DeleteCustomFieldDefinition(ctx context.Context, db DBTX, tenantID string) (int64, error) {
+	result, err := db.ExecContext(ctx, DeleteCustomFieldDefinition, tenantID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const DeleteCustomer = `-- name: DeleteCustomer :exec
+DELETE FROM customers
+WHERE id = $1
+`
+
+func (q *Queries) DeleteCustomer(ctx context.Context, db DBTX, id string) error {
+	_, err := db.ExecContext(ctx, DeleteCustomer, id)
+	return err
+}
+
+const DeleteCustomerDeletion = `-- name: DeleteCustomerDeletion :execrows
+DELETE FROM customer_deletions
+WHERE customer_id = $1 AND purged_at IS NULL
+`
+
+func (q *Queries) DeleteCustomerDeletion(ctx context.Context, db DBTX, customerID string) (int64, error) {
+	result, err := db.ExecContext(ctx, DeleteCustomerDeletion, customerID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const DeleteCustomerIdentity = `-- name: DeleteCustomerIdentity :exec
+DELETE FROM customer_identities
+WHERE customer_id = $1
+`
+
+func (q *Queries) DeleteCustomerIdentity(ctx context.Context, db DBTX, customerID string) error {
+	_, err := db.ExecContext(ctx, DeleteCustomerIdentity, customerID)
+	return err
+}
+
+const DeleteCustomerPaymentMethods = `-- name: DeleteCustomerPaymentMethods :exec
+DELETE FROM payment_methods
+WHERE customer_id = $1
+`
+
+func (q *Queries) DeleteCustomerPaymentMethods(ctx context.Context, db DBTX, customerID string) error {
+	_, err := db.ExecContext(ctx, DeleteCustomerPaymentMethods, customerID)
+	return err
+}
+
+const DeleteCustomerReferences = `-- name: DeleteCustomerReferences :exec
+DELETE FROM customer_references
+WHERE customer_id = $1
+`
+
+func (q *Queries) DeleteCustomerReferences(ctx context.Context, db DBTX, customerID string) error {
+	_, err := db.ExecContext(ctx, DeleteCustomerReferences, customerID)
+	return err
+}
+
+const DeleteDeadLetter = `-- name: DeleteDeadLetter :execrows
+DELETE FROM dlq_events
+WHERE id = $1
+`
+
+func (q *Queries) DeleteDeadLetter(ctx context.Context, db DBTX, id string) (int64, error) {
+	result, err := db.ExecContext(ctx, DeleteDeadLetter, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const DeleteDocumentTemplate = `-- name: DeleteDocumentTemplate :execrows
+DELETE FROM document_templates
+WHERE tenant_id = $1 AND kind = $2
+`
+
+type DeleteDocumentTemplateParams struct {
+	TenantID string `json:"tenant_id"`
+	Kind     string `json:"kind"`
+}
+
+func (q *Queries) DeleteDocumentTemplate(ctx context.Context, db DBTX, arg DeleteDocumentTemplateParams) (int64, error) {
+	result, err := db.ExecContext(ctx, DeleteDocumentTemplate, arg.TenantID, arg.Kind)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const DeleteFraudListEntry = `-- name: DeleteFraudListEntry :execrows
+DELETE FROM fraud_list_entries
+WHERE id = $1
+`
+
+func (q *Queries) DeleteFraudListEntry(ctx context.Context, db DBTX, id string) (int64, error) {
+	result, err := db.ExecContext(ctx, DeleteFraudListEntry, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const DeleteInvoice = `-- name: DeleteInvoice :exec
+DELETE FROM invoices
+WHERE id = $1
+`
+
+func (q *Queries) DeleteInvoice(ctx context.Context, db DBTX, id string) error {
+	_, err := db.ExecContext(ctx, DeleteInvoice, id)
+	return err
+}
+
+const DeleteMetadataSchema = `-- name: DeleteMetadataSchema :execrows
+DELETE FROM metadata_schemas
+WHERE tenant_id = $1 AND resource = $2
+`
+
+type DeleteMetadataSchemaParams struct {
+	TenantID string `json:"tenant_id"`
+	Resource string `json:"resource"`
+}
+
+func (q *Queries) DeleteMetadataSchema(ctx context.Context, db DBTX, arg DeleteMetadataSchemaParams) (int64, error) {
+	result, err := db.ExecContext(ctx, DeleteMetadataSchema, arg.TenantID, arg.Resource)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const DeleteMirroredPaymentMethod = `-- name: DeleteMirroredPaymentMethod :exec
+DELETE FROM payment_methods
+WHERE id = $1 AND synced_at <= $2
+`
+
+type DeleteMirroredPaymentMethodParams struct {
+	ID       string    `json:"id"`
+	SyncedAt time.Time `json:"synced_at"`
+}
+
+func (q *Queries) DeleteMirroredPaymentMethod(ctx context.Context, db DBTX, arg DeleteMirroredPaymentMethodParams) error {
+	_, err := db.ExecContext(ctx, DeleteMirroredPaymentMethod, arg.ID, arg.SyncedAt)
+	return err
+}
+
+const DeleteOutboxEvent = `-- name: DeleteOutboxEvent :exec
+DELETE FROM event_outbox
+WHERE id = $1
+`
+
+func (q *Queries) DeleteOutboxEvent(ctx context.Context, db DBTX, id string) error {
+	_, err := db.ExecContext(ctx, DeleteOutboxEvent, id)
+	return err
+}
+
+const DeletePaymentMethod = `-- name: DeletePaymentMethod :exec
+DELETE FROM payment_methods
+WHERE id = $1 AND customer_id = $2
+`
+
+type DeletePaymentMethodParams struct {
+	ID         string `json:"id"`
+	CustomerID string `json:"customer_id"`
+}
+
+func (q *Queries) DeletePaymentMethod(ctx context.Context, db DBTX, arg DeletePaymentMethodParams) error {
+	_, err := db.ExecContext(ctx, DeletePaymentMethod, arg.ID, arg.CustomerID)
+	return err
+}
+
+const DeleteProviderCredential = `-- name: DeleteProviderCredential :execrows
+DELETE FROM provider_credentials
+WHERE tenant_id = $1 AND provider = $2
+`
+
+type DeleteProviderCredentialParams struct {
+	TenantID string `json:"tenant_id"`
+	Provider string `json:"provider"`
+}
+
+func (q *Queries) DeleteProviderCredential(ctx context.Context, db DBTX, arg DeleteProviderCredentialParams) (int64, error) {
+	result, err := db.ExecContext(ctx, DeleteProviderCredential, arg.TenantID, arg.Provider)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const DeleteSimulatorObject = `-- name: DeleteSimulatorObject :execrows
+DELETE FROM simulator_objects
+WHERE tenant_id = $1 AND kind = $2 AND id = $3
+`
+
+type DeleteSimulatorObjectParams struct {
+	TenantID string `json:"tenant_id"`
+	Kind     string `json:"kind"`
+	ID       string `json:"id"`
+}
+
+func (q *Queries) DeleteSimulatorObject(ctx context.Context, db DBTX, arg DeleteSimulatorObjectParams) (int64, error) {
+	result, err := db.ExecContext(ctx, DeleteSimulatorObject, arg.TenantID, arg.Kind, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const DeleteSmartRoutingRule = `-- name: DeleteSmartRoutingRule :execrows
+DELETE FROM smart_routing_rules
+WHERE id = $1
+`
+
+func (q *Queries) DeleteSmartRoutingRule(ctx context.Context, db DBTX, id string) (int64, error) {
+	result, err := db.ExecContext(ctx, DeleteSmartRoutingRule, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const DeleteTenantBudget = `-- name: DeleteTenantBudget :execrows
+DELETE FROM tenant_budgets
+WHERE tenant_id = $1
+`
+
+func (q *Queries) DeleteTenantBudget(ctx context.Context, db DBTX, tenantID string) (int64, error) {
+	result, err := db.ExecContext(ctx, DeleteTenantBudget, tenantID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const DeleteVaultToken = `-- name: DeleteVaultToken :exec
+DELETE FROM vault_tokens
+WHERE id = $1
+`
+
+func (q *Queries) DeleteVaultToken(ctx context.Context, db DBTX, id string) error {
+	_, err := db.ExecContext(ctx, DeleteVaultToken, id)
+	return err
+}
+
+const DeleteWebhookEndpoint = `-- name: DeleteWebhookEndpoint :execrows
+DELETE FROM webhook_endpoints
+WHERE tenant_id = $1 AND id = $2
+`
+
+type DeleteWebhookEndpointParams struct {
+	TenantID string `json:"tenant_id"`
+	ID       string `json:"id"`
+}
+
+func (q *Queries) DeleteWebhookEndpoint(ctx context.Context, db DBTX, arg DeleteWebhookEndpointParams) (int64, error) {
+	result, err := db.ExecContext(ctx, DeleteWebhookEndpoint, arg.TenantID, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const ExpireAPIKey = `-- name: ExpireAPIKey :execrows
+UPDATE api_keys
+SET expires_at = $2
+WHERE id = $1 AND revoked_at IS NULL
+`
+
+type ExpireAPIKeyParams struct {
+	ID        string       `json:"id"`
+	ExpiresAt sql.NullTime `json:"expires_at"`
+}
+
+func (q *Queries) ExpireAPIKey(ctx context.Context, db DBTX, arg ExpireAPIKeyParams) (int64, error) {
+	result, err := db.ExecContext(ctx, ExpireAPIKey, arg.ID, arg.ExpiresAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const FailOffboardingExport = `-- name: FailOffboardingExport :one
+UPDATE offboarding_exports
+SET status = 'failed', error = $2
+WHERE id = $1 AND status = 'running'
+RETURNING id, tenant_id, status, recipient_public_key, manifest, archive_sha256, archive_size, error, pan_migration_status, pan_migration_destination, pan_migration_reference, pan_migration_requested_at, started_at, completed_at, created_at, updated_at
+`
+
+type FailOffboardingExportParams struct {
+	ID    string `json:"id"`
+	Error string `json:"error"`
+}
+
+func (q *Queries) FailOffboardingExport(ctx context.Context, db DBTX, arg FailOffboardingExportParams) (OffboardingExport, error) {
+	row := db.QueryRowContext(ctx, FailOffboardingExport, arg.ID, arg.Error)
+	var i OffboardingExport
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.Status,
+		&i.RecipientPublicKey,
+		&i.Manifest,
+		&i.ArchiveSha256,
+		&i.ArchiveSize,
+		&i.Error,
+		&i.PanMigrationStatus,
+		&i.PanMigrationDestination,
+		&i.PanMigrationReference,
+		&i.PanMigrationRequestedAt,
+		&i.StartedAt,
+		&i.CompletedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const FinishJobRun = `-- name: FinishJobRun :one
+UPDATE job_runs
+SET status = $2, attempts = $3, error = $4, finished_at = $5
+WHERE id = $1
+RETURNING id, job_name, status, triggered_by, attempts, error, instance, started_at, finished_at, created_at
+`
+
+type FinishJobRunParams struct {
+	ID         string       `json:"id"`
+	Status     string       `json:"status"`
+	Attempts   int32        `json:"attempts"`
+	Error      string       `json:"error"`
+	FinishedAt sql.NullTime `json:"finished_at"`
+}
+
+func (q *Queries) FinishJobRun(ctx context.Context, db DBTX, arg FinishJobRunParams) (JobRun, error) {
+	row := db.QueryRowContext(ctx, FinishJobRun,
+		arg.ID,
+		arg.Status,
+		arg.Attempts,
+		arg.Error,
+		arg.FinishedAt,
+	)
+	var i JobRun
+	err := row.Scan(
+		&i.ID,
+		&i.JobName,
+		&i.Status,
+		&i.TriggeredBy,
+		&i.Attempts,
+		&i.Error,
+		&i.Instance,
+		&i.StartedAt,
+		&i.FinishedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const FinishRefundBatch = `-- name: FinishRefundBatch :one
+UPDATE refund_batches
+SET status = $1, completed_at = NOW()
+WHERE id = $2 AND status = $3
+RETURNING id, tenant_id, api_key_id, operator_id, requests, status, scheduled_at, started_at, completed_at, created_at, updated_at
+`
+
+type FinishRefundBatchParams struct {
+	Status     string `json:"status"`
+	ID         string `json:"id"`
+	FromStatus string `json:"from_status"`
+}
+
+func (q *Queries) FinishRefundBatch(ctx context.Context, db DBTX, arg FinishRefundBatchParams) (RefundBatch, error) {
+	row := db.QueryRowContext(ctx, FinishRefundBatch, arg.Status, arg.ID, arg.FromStatus)
+	var i RefundBatch
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.ApiKeyID,
+		&i.OperatorID,
+		&i.Requests,
+		&i.Status,
+		&i.ScheduledAt,
+		&i.StartedAt,
+		&i.CompletedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const FinishWebhookSecretRotation = `-- name: FinishWebhookSecretRotation :one
+UPDATE webhook_secret_rotations
+SET status = $1, completed_at = $2, cancelled_at = $3
+WHERE id = $4 AND status = 'pending'
+RETURNING id, status, old_endpoint_id, new_endpoint_id, new_secret, deliveries, confirmed_at, grace_ends_at, completed_at, cancelled_at, created_at, updated_at
+`
+
+type FinishWebhookSecretRotationParams struct {
+	Status      string       `json:"status"`
+	CompletedAt sql.NullTime `json:"completed_at"`
+	CancelledAt sql.NullTime `json:"cancelled_at"`
+	ID          string       `json:"id"`
+}
+
+func (q *Queries) FinishWebhookSecretRotation(ctx context.Context, db DBTX, arg FinishWebhookSecretRotationParams) (WebhookSecretRotation, error) {
+	row := db.QueryRowContext(ctx, FinishWebhookSecretRotation,
+		arg.Status,
+		arg.CompletedAt,
+		arg.CancelledAt,
+		arg.ID,
+	)
+	var i WebhookSecretRotation
+	err := row.Scan(
+		&i.ID,
+		&i.Status,
+		&i.OldEndpointID,
+		&i.NewEndpointID,
+		&i.NewSecret,
+		&i.Deliveries,
+		&i.ConfirmedAt,
+		&i.GraceEndsAt,
+		&i.CompletedAt,
+		&i.CancelledAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const GetAPIKey = `-- name: GetAPIKey :one
+SELECT id, name, secret_hash, secret_hint, scopes, tenant_id, created_by, rotated_from, expires_at, revoked_at, created_at FROM api_keys
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetAPIKey(ctx context.Context, db DBTX, id string) (ApiKey, error) {
+	row := db.QueryRowContext(ctx, GetAPIKey, id)
+	var i ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.SecretHash,
+		&i.SecretHint,
+		&i.Scopes,
+		&i.TenantID,
+		&i.CreatedBy,
+		&i.RotatedFrom,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const GetAPIKeyBySecretHash = `-- name: GetAPIKeyBySecretHash :one
+SELECT id, name, secret_hash, secret_hint, scopes, tenant_id, created_by, rotated_from, expires_at, revoked_at, created_at FROM api_keys
+WHERE secret_hash = $1
+`
+
+func (q *Queries) GetAPIKeyBySecretHash(ctx context.Context, db DBTX, secretHash string) (ApiKey, error) {
+	row := db.QueryRowContext(ctx, GetAPIKeyBySecretHash, secretHash)
+	var i ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.SecretHash,
+		&i.SecretHint,
+		&i.Scopes,
+		&i.TenantID,
+		&i.CreatedBy,
+		&i.RotatedFrom,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const GetActiveCustomerHoldBySource = `-- name: GetActiveCustomerHoldBySource :one
+SELECT id, tenant_id, customer_id, reason, source_id, status, blocks_charges, paused_subscriptions, release_reason, created_at, updated_at, released_at FROM customer_holds
+WHERE source_id = $1 AND status = 'active'
+LIMIT 1
+`
+
+func (q *Queries) GetActiveCustomerHoldBySource(ctx context.Context, db DBTX, sourceID string) (CustomerHold, error) {
+	row := db.QueryRowContext(ctx, GetActiveCustomerHoldBySource, sourceID)
+	var i CustomerHold
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.CustomerID,
+		&i.Reason,
+		&i.SourceID,
+		&i.Status,
+		&i.BlocksCharges,
+		&i.PausedSubscriptions,
+		&i.ReleaseReason,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ReleasedAt,
+	)
+	return i, err
+}
+
+const GetActiveQuarantine = `-- name: GetActiveQuarantine :one
+SELECT id, subject_type, subject_id, reason, created_by, lifted_by, lifted_at, created_at, updated_at FROM quarantines
+WHERE subject_type = $1 AND subject_id = $2 AND lifted_at IS NULL
+`
+
+type GetActiveQuarantineParams struct {
+	SubjectType string `json:"subject_type"`
+	SubjectID   string `json:"subject_id"`
+}
+
+func (q *Queries) GetActiveQuarantine(ctx context.Context, db DBTX, arg GetActiveQuarantineParams) (Quarantine, error) {
+	row := db.QueryRowContext(ctx, GetActiveQuarantine, arg.SubjectType, arg.SubjectID)
+	var i Quarantine
+	err := row.Scan(
+		&i.ID,
+		&i.SubjectType,
+		&i.SubjectID,
+		&i.Reason,
+		&i.CreatedBy,
+		&i.LiftedBy,
+		&i.LiftedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const GetAuditEntry = `-- name: GetAuditEntry :one
+SELECT sequence, tenant_id, actor_type, actor_id, operator_id, action, resource_type, resource_id, method, path, status, before_state, after_state, changes, ip, request_id, created_at, previous_hash, hash FROM audit_log
+WHERE sequence = $1
+`
+
+func (q *Queries) GetAuditEntry(ctx context.Context, db DBTX, sequence int64) (AuditLog, error) {
+	row := db.QueryRowContext(ctx, GetAuditEntry, sequence)
+	var i AuditLog
+	err := row.Scan(
+		&i.Sequence,
+		&i.TenantID,
+		&i.ActorType,
+		&i.ActorID,
+		&i.OperatorID,
+		&i.Action,
+		&i.ResourceType,
+		&i.ResourceID,
+		&i.Method,
+		&i.Path,
+		&i.Status,
+		&i.BeforeState,
+		&i.AfterState,
+		&i.Changes,
+		&i.Ip,
+		&i.RequestID,
+		&i.CreatedAt,
+		&i.PreviousHash,
+		&i.Hash,
+	)
+	return i, err
+}
+
+const GetAuthorization = `-- name: GetAuthorization :one
+SELECT charge_id, tenant_id, provider, amount, amount_captured, currency, captures, multi_capture, status, expires_at, closed_at, created_at, updated_at FROM authorizations
+WHERE charge_id = $1 AND ($2 = '' OR tenant_id = $2)
+`
+
+type GetAuthorizationParams struct {
+	ChargeID string `json:"charge_id"`
+	TenantID string `json:"tenant_id"`
+}
+
+func (q *Queries) GetAuthorization(ctx context.Context, db DBTX, arg GetAuthorizationParams) (Authorization, error) {
+	row := db.QueryRowContext(ctx, GetAuthorization, arg.ChargeID, arg.TenantID)
+	var i Authorization
+	err := row.Scan(
+		&i.ChargeID,
+		&i.TenantID,
+		&i.Provider,
+		&i.Amount,
+		&i.AmountCaptured,
+		&i.Currency,
+		&i.Captures,
+		&i.MultiCapture,
+		&i.Status,
+		&i.ExpiresAt,
+		&i.ClosedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const GetAutoRefundExclusion = `-- name: GetAutoRefundExclusion :one
+SELECT customer_id, reason, created_at FROM auto_refund_exclusions
+WHERE customer_id = $1 LIMIT 1
+`
+
+func (q *Queries) GetAutoRefundExclusion(ctx context.Context, db DBTX, customerID string) (AutoRefundExclusion, error) {
+	row := db.QueryRowContext(ctx, GetAutoRefundExclusion, customerID)
+	var i AutoRefundExclusion
+	err := row.Scan(&i.CustomerID, &i.Reason, &i.CreatedAt)
+	return i, err
+}
+
+const GetBlocklistEntry = `-- name: GetBlocklistEntry :one
+SELECT id, entry_type, value, reason, source, created_by, provider_item_id, created_at, updated_at FROM blocklist_entries
+WHERE id = $1
+`
+
+func (q *Queries) GetBlocklistEntry(ctx context.Context, db DBTX, id string) (BlocklistEntry, error) {
+	row := db.QueryRowContext(ctx, GetBlocklistEntry, id)
+	var i BlocklistEntry
+	err := row.Scan(
+		&i.ID,
+		&i.EntryType,
+		&i.Value,
+		&i.Reason,
+		&i.Source,
+		&i.CreatedBy,
+		&i.ProviderItemID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const GetBlocklistEntryByValue = `-- name: GetBlocklistEntryByValue :one
+SELECT id, entry_type, value, reason, source, created_by, provider_item_id, created_at, updated_at FROM blocklist_entries
+WHERE entry_type = $1 AND value = $2
+`
+
+type GetBlocklistEntryByValueParams struct {
+	EntryType string `json:"entry_type"`
+	Value     string `json:"value"`
+}
+
+func (q *Queries) GetBlocklistEntryByValue(ctx context.Context, db DBTX, arg GetBlocklistEntryByValueParams) (BlocklistEntry, error) {
+	row := db.QueryRowContext(ctx, GetBlocklistEntryByValue, arg.EntryType, arg.Value)
+	var i BlocklistEntry
+	err := row.Scan(
+		&i.ID,
+		&i.EntryType,
+		&i.Value,
+		&i.Reason,
+		&i.Source,
+		&i.CreatedBy,
+		&i.ProviderItemID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const GetCharge = `-- name: GetCharge :one
+SELECT id, amount, currency, status, customer_id, payment_method_id, description, metadata, created_at, updated_at, amount_refunded, captured, refunded, disputed, invoice_id, synced_at, tenant_id FROM charges
+WHERE id = $1 AND ($2 = '' OR tenant_id = $2) LIMIT 1
+`
+
+type GetChargeParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenant_id"`
+}
+
+func (q *Queries) GetCharge(ctx context.Context, db DBTX, arg GetChargeParams) (Charge, error) {
+	row := db.QueryRowContext(ctx, GetCharge, arg.ID, arg.TenantID)
+	var i Charge
+	err := row.Scan(
+		&i.ID,
+		&i.Amount,
+		&i.Currency,
+		&i.Status,
+		&i.CustomerID,
+		&i.PaymentMethodID,
+		&i.Description,
+		&i.Metadata,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.AmountRefunded,
+		&i.Captured,
+		&i.Refunded,
+		&i.Disputed,
+		&i.InvoiceID,
+		&i.SyncedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const GetChargeCredentialStats = `-- name: GetChargeCredentialStats :many
+SELECT credential_type, network, wallet,
+    COUNT(*) AS charge_count,
+    COUNT(*) FILTER (WHERE status = 'succeeded') AS succeeded_count,
+    COALESCE(SUM(amount) FILTER (WHERE status = 'succeeded'), 0)::bigint AS succeeded_amount
+FROM charge_credentials
+WHERE tenant_id = $1 AND currency = $2 AND created_at >= $3
+GROUP BY credential_type, network, wallet
+ORDER BY credential_type, network, wallet
+`
+
+type GetChargeCredentialStatsParams struct {
+	TenantID  string       `json:"tenant_id"`
+	Currency  string       `json:"currency"`
+	CreatedAt sql.NullTime `json:"created_at"`
+}
+
+type GetChargeCredentialStatsRow struct {
+	CredentialType  string `json:"credential_type"`
+	Network         string `json:"network"`
+	Wallet          string `json:"wallet"`
+	ChargeCount     int64  `json:"charge_count"`
+	SucceededCount  int64  `json:"succeeded_count"`
+	SucceededAmount int64  `json:"succeeded_amount"`
+}
+
+func (q *Queries) GetChargeCredentialStats(ctx context.Context, db DBTX, arg GetChargeCredentialStatsParams) ([]GetChargeCredentialStatsRow, error) {
+	rows, err := db.QueryContext(ctx, GetChargeCredentialStats, arg.TenantID, arg.Currency, arg.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetChargeCredentialStatsRow{}
+	for rows.Next() {
+		var i GetChargeCredentialStatsRow
+		if err := rows.Scan(
+			&i.CredentialType,
+			&i.Network,
+			&i.Wallet,
+			&i.ChargeCount,
+			&i.SucceededCount,
+			&i.SucceededAmount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const GetChargeCustomer = `-- name: GetChargeCustomer :one
+SELECT customer_id FROM charges
+WHERE id = $1
+`
+
+func (q *Queries) GetChargeCustomer(ctx context.Context, db DBTX, id string) (string, error) {
+	row := db.QueryRowContext(ctx, GetChargeCustomer, id)
+	var customer_id string
+	err := row.Scan(&customer_id)
+	return customer_id, err
+}
+
+const GetChargeStats = `-- name: GetChargeStats :one
+SELECT 
+    COUNT(*) as total_charges,
+    SUM(amount) as total_amount,
+    COUNT(CASE WHEN status = 'succeeded' THEN 1 END) as successful_charges,
+    SUM(CASE WHEN status = 'succeeded' THEN amount ELSE 0 END) as successful_amount
+FROM charges
+`
+
+type GetChargeStatsRow struct {
+	TotalCharges      int64 `json:"total_charges"`
+	TotalAmount       int64 `json:"total_amount"`
+	SuccessfulCharges int64 `json:"successful_charges"`
+	SuccessfulAmount  int64 `json:"successful_amount"`
+}
+
+func (q *Queries) GetChargeStats(ctx context.Context, db DBTX) (GetChargeStatsRow, error) {
+	row := db.QueryRowContext(ctx, GetChargeStats)
+	var i GetChargeStatsRow
+	err := row.Scan(
+		&i.TotalCharges,
+		&i.TotalAmount,
+		&i.SuccessfulCharges,
+		&i.SuccessfulAmount,
+	)
+	return i, err
+}
+
+const GetChargeTenant = `-- name: GetChargeTenant :one
+SELECT tenant_id FROM charges
+WHERE id = $1
+`
+
+func (q *Queries) GetChargeTenant(ctx context.Context, db DBTX, id string) (string, error) {
+	row := db.QueryRowContext(ctx, GetChargeTenant, id)
+	var tenant_id string
+	err := row.Scan(&tenant_id)
+	return tenant_id, err
+}
+
+const GetCompositeCharge = `-- name: GetCompositeCharge :one
+SELECT id, tenant_id, amount, currency, refund_strategy, created_at, updated_at FROM composite_charges
+WHERE id = $1 AND ($2 = '' OR tenant_id = $2)
+`
+
+type GetCompositeChargeParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenant_id"`
+}
+
+func (q *Queries) GetCompositeCharge(ctx context.Context, db DBTX, arg GetCompositeChargeParams) (CompositeCharge, error) {
+	row := db.QueryRowContext(ctx, GetCompositeCharge, arg.ID, arg.TenantID)
+	var i CompositeCharge
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.Amount,
+		&i.Currency,
+		&i.RefundStrategy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const GetCompositeRefund = `-- name: GetCompositeRefund :one
+SELECT id, composite_charge_id, tenant_id, amount, currency, reason, status, created_at, updated_at FROM composite_refunds
+WHERE id = $1 AND ($2 = '' OR tenant_id = $2)
+`
+
+type GetCompositeRefundParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenant_id"`
+}
+
+func (q *Queries) GetCompositeRefund(ctx context.Context, db DBTX, arg GetCompositeRefundParams) (CompositeRefund, error) {
+	row := db.QueryRowContext(ctx, GetCompositeRefund, arg.ID, arg.TenantID)
+	var i CompositeRefund
+	err := row.Scan(
+		&i.ID,
+		&i.CompositeChargeID,
+		&i.TenantID,
+		&i.Amount,
+		&i.Currency,
+		&i.Reason,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const GetCustomFieldDefinition = `-- name: GetCustomFieldDefinition :one
+SELECT tenant_id, fields, created_at, updated_at FROM custom_field_definitions
+WHERE tenant_id = $1 LIMIT 1
+`
+
+func (q *Queries) GetCustomFieldDefinition(ctx context.Context, db DBTX, tenantID string) (CustomFieldDefinition, error) {
+	row := db.QueryRowContext(ctx, GetCustomFieldDefinition, tenantID)
+	var i CustomFieldDefinition
+	err := row.Scan(
+		&i.TenantID,
+		&i.Fields,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const GetCustomer = `-- name: GetCustomer :one
+SELECT id, email, name, phone, description, metadata, created_at, updated_at, synced_at, tenant_id FROM customers
+WHERE id = $1 AND ($2 = '' OR tenant_id = $2) LIMIT 1
+`
+
+type GetCustomerParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenant_id"`
+}
+
+func (q *Queries) GetCustomer(ctx context.Context, db DBTX, arg GetCustomerParams) (Customer, error) {
+	row := db.QueryRowContext(ctx, GetCustomer, arg.ID, arg.TenantID)
+	var i Customer
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.Name,
+		&i.Phone,
+		&i.Description,
+		&i.Metadata,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SyncedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const GetCustomerBalance = `-- name: GetCustomerBalance :one
+SELECT tenant_id, customer_id, currency, amount, created_at, updated_at FROM customer_balances
+WHERE tenant_id = $1 AND customer_id = $2 AND currency = $3 LIMIT 1
+`
+
+type GetCustomerBalanceParams struct {
+	TenantID   string `json:"tenant_id"`
+	CustomerID string `json:"customer_id"`
+	Currency   string `json:"currency"`
+}
+
+func (q *Queries) GetCustomerBalance(ctx context.Context, db DBTX, arg GetCustomerBalanceParams) (CustomerBalance, error) {
+	row := db.QueryRowContext(ctx, GetCustomerBalance, arg.TenantID, arg.CustomerID, arg.Currency)
+	var i CustomerBalance
+	err := row.Scan(
+		&i.TenantID,
+		&i.CustomerID,
+		&i.Currency,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const GetCustomerBalanceTransactionBySource = `-- name: GetCustomerBalanceTransactionBySource :one
+SELECT id, tenant_id, customer_id, type, reason, amount, currency, balance_after, description, source_id, provider_transaction_id, created_by, created_at FROM customer_balance_transactions
+WHERE tenant_id = $1 AND type = $2 AND source_id = $3
+ORDER BY created_at LIMIT 1
+`
+
+type GetCustomerBalanceTransactionBySourceParams struct {
+	TenantID string `json:"tenant_id"`
+	Type     string `json:"type"`
+	SourceID string `json:"source_id"`
+}
+
+func (q *Queries) GetCustomerBalanceTransactionBySource(ctx context.Context, db DBTX, arg GetCustomerBalanceTransactionBySourceParams) (CustomerBalanceTransaction, error) {
+	row := db.QueryRowContext(ctx, GetCustomerBalanceTransactionBySource, arg.TenantID, arg.Type, arg.SourceID)
+	var i CustomerBalanceTransaction
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.CustomerID,
+		&i.Type,
+		&i.Reason,
+		&i.Amount,
+		&i.Currency,
+		&i.BalanceAfter,
+		&i.Description,
+		&i.SourceID,
+		&i.ProviderTransactionID,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const GetCustomerByEmail = `-- name: GetCustomerByEmail :one
+SELECT id, email, name, phone, description, metadata, created_at, updated_at, synced_at, tenant_id FROM customers
+WHERE email = $1 LIMIT 1
+`
+
+func (q *Queries) GetCustomerByEmail(ctx context.Context, db DBTX, email string) (Customer, error) {
+	row := db.QueryRowContext(ctx, GetCustomerByEmail, email)
+	var i Customer
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.Name,
+		&i.Phone,
+		&i.Description,
+		&i.Metadata,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SyncedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const GetCustomerChargeStats = `-- name: GetCustomerChargeStats :many
+SELECT
+    currency,
+    COUNT(*) AS charge_count,
+    COUNT(*) FILTER (WHERE status = 'succeeded') AS succeeded_count,
+    COALESCE(SUM(amount) FILTER (WHERE status = 'succeeded'), 0)::bigint AS succeeded_amount,
+    COALESCE(SUM(amount_refunded) FILTER (WHERE status = 'succeeded'), 0)::bigint AS refunded_amount,
+    COUNT(*) FILTER (WHERE status = 'succeeded' AND amount_refunded > 0) AS refunded_count,
+    COUNT(*) FILTER (WHERE disputed) AS dispute_count,
+    MIN(created_at)::timestamptz AS first_charge_at,
+    MAX(created_at)::timestamptz AS last_charge_at
+FROM charges
+WHERE customer_id = $1 AND created_at >= $2
+GROUP BY currency
+ORDER BY currency
+`
+
+type GetCustomerChargeStatsParams struct {
+	CustomerID  string       `json:"customer_id"`
+	CreatedFrom sql.NullTime `json:"created_from"`
+}
+
+type GetCustomerChargeStatsRow struct {
+	Currency        string    `json:"currency"`
+	ChargeCount     int64     `json:"charge_count"`
+	SucceededCount  int64     `json:"succeeded_count"`
+	SucceededAmount int64     `json:"succeeded_amount"`
+	RefundedAmount  int64     `json:"refunded_amount"`
+	RefundedCount   int64     `json:"refunded_count"`
+	DisputeCount    int64     `json:"dispute_count"`
+	FirstChargeAt   time.Time `json:"first_charge_at"`
+	LastChargeAt    time.Time `json:"last_charge_at"`
+}
+
+func (q *Queries) GetCustomerChargeStats(ctx context.Context, db DBTX, arg GetCustomerChargeStatsParams) ([]GetCustomerChargeStatsRow, error) {
+	rows, err := db.QueryContext(ctx, GetCustomerChargeStats, arg.CustomerID, arg.CreatedFrom)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetCustomerChargeStatsRow{}
+	for rows.Next() {
+		var i GetCustomerChargeStatsRow
+		if err := rows.Scan(
+			&i.Currency,
+			&i.ChargeCount,
+			&i.SucceededCount,
+			&i.SucceededAmount,
+			&i.RefundedAmount,
+			&i.RefundedCount,
+			&i.DisputeCount,
+			&i.FirstChargeAt,
+			&i.LastChargeAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const GetCustomerDeletion = `-- name: GetCustomerDeletion :one
+SELECT customer_id, tenant_id, deleted_at, purge_after, purged_at, created_at FROM customer_deletions
+WHERE customer_id = $1
+`
+
+func (q *Queries) GetCustomerDeletion(ctx context.Context, db DBTX, customerID string) (CustomerDeletion, error) {
+	row := db.QueryRowContext(ctx, GetCustomerDeletion, customerID)
+	var i CustomerDeletion
+	err := row.Scan(
+		&i.CustomerID,
+		&i.TenantID,
+		&i.DeletedAt,
+		&i.PurgeAfter,
+		&i.PurgedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const GetCustomerErasure = `-- name: GetCustomerErasure :one
+SELECT customer_id, tenant_id, erased_at, created_at FROM customer_erasures
+WHERE customer_id = $1
+`
+
+func (q *Queries) GetCustomerErasure(ctx context.Context, db DBTX, customerID string) (CustomerErasure, error) {
+	row := db.QueryRowContext(ctx, GetCustomerErasure, customerID)
+	var i CustomerErasure
+	err := row.Scan(
+		&i.CustomerID,
+		&i.TenantID,
+		&i.ErasedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const GetCustomerHold = `-- name: GetCustomerHold :one
+SELECT id, tenant_id, customer_id, reason, source_id, status, blocks_charges, paused_subscriptions, release_reason, created_at, updated_at, released_at FROM customer_holds
+WHERE id = $1 AND ($2 = '' OR tenant_id = $2) LIMIT 1
+`
+
+type GetCustomerHoldParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenant_id"`
+}
+
+func (q *Queries) GetCustomerHold(ctx context.Context, db DBTX, arg GetCustomerHoldParams) (CustomerHold, error) {
+	row := db.QueryRowContext(ctx, GetCustomerHold, arg.ID, arg.TenantID)
+	var i CustomerHold
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.CustomerID,
+		&i.Reason,
+		&i.SourceID,
+		&i.Status,
+		&i.BlocksCharges,
+		&i.PausedSubscriptions,
+		&i.ReleaseReason,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ReleasedAt,
+	)
+	return i, err
+}
+
+const GetCustomerIdentity = `-- name: GetCustomerIdentity :one
+SELECT customer_id, tenant_id, normalized_email, name, email_verified_at, verification_token_hash, verification_expires_at, created_at, updated_at FROM customer_identities
+WHERE customer_id = $1
+`
+
+func (q *Queries) GetCustomerIdentity(ctx context.Context, db DBTX, customerID string) (CustomerIdentity, error) {
+	row := db.QueryRowContext(ctx, GetCustomerIdentity, customerID)
+	var i CustomerIdentity
+	err := row.Scan(
+		&i.CustomerID,
+		&i.TenantID,
+		&i.NormalizedEmail,
+		&i.Name,
+		&i.EmailVerifiedAt,
+		&i.VerificationTokenHash,
+		&i.VerificationExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const GetCustomerReference = `-- name: GetCustomerReference :one
+SELECT tenant_id, external_reference, customer_id, created_at FROM customer_references
+WHERE tenant_id = $1 AND external_reference = $2 LIMIT 1
+`
+
+type GetCustomerReferenceParams struct {
+	TenantID          string `json:"tenant_id"`
+	ExternalReference string `json:"external_reference"`
+}
+
+func (q *Queries) GetCustomerReference(ctx context.Context, db DBTX, arg GetCustomerReferenceParams) (CustomerReference, error) {
+	row := db.QueryRowContext(ctx, GetCustomerReference, arg.TenantID, arg.ExternalReference)
+	var i CustomerReference
+	err := row.Scan(
+		&i.TenantID,
+		&i.ExternalReference,
+		&i.CustomerID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const GetCustomerScreenedAmounts = `-- name: GetCustomerScreenedAmounts :one
+SELECT COUNT(*) AS charge_count, COALESCE(AVG(amount), 0)::bigint AS average_amount
+FROM fraud_screenings
+WHERE tenant_id = $1 AND customer_id = $2 AND currency = $3
+  AND decision = 'accept' AND created_at >= $4
+`
+
+type GetCustomerScreenedAmountsParams struct {
+	TenantID   string       `json:"tenant_id"`
+	CustomerID string       `json:"customer_id"`
+	Currency   string       `json:"currency"`
+	CreatedAt  sql.NullTime `json:"created_at"`
+}
+
+type GetCustomerScreenedAmountsRow struct {
+	ChargeCount   int64 `json:"charge_count"`
+	AverageAmount int64 `json:"average_amount"`
+}
+
+func (q *Queries) GetCustomerScreenedAmounts(ctx context.Context, db DBTX, arg GetCustomerScreenedAmountsParams) (GetCustomerScreenedAmountsRow, error) {
+	row := db.QueryRowContext(ctx, GetCustomerScreenedAmounts,
+		arg.TenantID,
+		arg.CustomerID,
+		arg.Currency,
+		arg.CreatedAt,
+	)
+	var i GetCustomerScreenedAmountsRow
+	err := row.Scan(&i.ChargeCount, &i.AverageAmount)
+	return i, err
+}
+
+const GetCustomerStats = `-- name: GetCustomerStats :one
+SELECT 
+    COUNT(*) as total_customers,
+    COUNT(CASE WHEN created_at >= NOW() - INTERVAL '30 days' THEN 1 END) as new_customers_30d,
+    COUNT(CASE WHEN created_at >= NOW() - INTERVAL '7 days' THEN 1 END) as new_customers_7d
+FROM customers
+`
+
+type GetCustomerStatsRow struct {
+	TotalCustomers  int64 `json:"total_customers"`
+	NewCustomers30d int64 `json:"new_customers_30d"`
+	NewCustomers7d  int64 `json:"new_customers_7d"`
+}
+
+func (q *Queries) GetCustomerStats(ctx context.Context, db DBTX) (GetCustomerStatsRow, error) {
+	row := db.QueryRowContext(ctx, GetCustomerStats)
+	var i GetCustomerStatsRow
+	err := row.Scan(&i.TotalCustomers, &i.NewCustomers30d, &i.NewCustomers7d)
+	return i, err
+}
+
+const GetDeadLetter = `-- name: GetDeadLetter :one
+SELECT id, source, dedupe_key, payload, headers, status, attempts, last_error, next_attempt_at, resolved_at, created_at, updated_at FROM dlq_events
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetDeadLetter(ctx context.Context, db DBTX, id string) (DlqEvent, error) {
+	row := db.QueryRowContext(ctx, GetDeadLetter, id)
+	var i DlqEvent
+	err := row.Scan(
+		&i.ID,
+		&i.Source,
+		&i.DedupeKey,
+		&i.Payload,
+		&i.Headers,
+		&i.Status,
+		&i.Attempts,
+		&i.LastError,
+		&i.NextAttemptAt,
+		&i.ResolvedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const GetDispute = `-- name: GetDispute :one
+SELECT id, charge_id, payment_intent_id, amount, currency, reason, status, network_reason_code, is_charge_refundable, evidence, evidence_due_by, has_evidence, past_due, submission_count, payment_method_type, card_brand, card_network_reason_code, balance_transactions, livemode, metadata, disputed_at, synced_at, created_at, updated_at, tenant_id FROM disputes
+WHERE id = $1 AND ($2 = '' OR tenant_id = $2)
+`
+
+type GetDisputeParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenant_id"`
+}
+
+func (q *Queries) GetDispute(ctx context.Context, db DBTX, arg GetDisputeParams) (Dispute, error) {
+	row := db.QueryRowContext(ctx, GetDispute, arg.ID, arg.TenantID)
+	var i Dispute
+	err := row.Scan(
+		&i.ID,
+		&i.ChargeID,
+		&i.PaymentIntentID,
+		&i.Amount,
+		&i.Currency,
+		&i.Reason,
+		&i.Status,
+		&i.NetworkReasonCode,
+		&i.IsChargeRefundable,
+		&i.Evidence,
+		&i.EvidenceDueBy,
+		&i.HasEvidence,
+		&i.PastDue,
+		&i.SubmissionCount,
+		&i.PaymentMethodType,
+		&i.CardBrand,
+		&i.CardNetworkReasonCode,
+		&i.BalanceTransactions,
+		&i.Livemode,
+		&i.Metadata,
+		&i.DisputedAt,
+		&i.SyncedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const GetDisputeEvidence = `-- name: GetDisputeEvidence :one
+SELECT dispute_id, fields, updated_by, submitted_by, submitted_at, created_at, updated_at FROM dispute_evidence
+WHERE dispute_id = $1
+`
+
+func (q *Queries) GetDisputeEvidence(ctx context.Context, db DBTX, disputeID string) (DisputeEvidence, error) {
+	row := db.QueryRowContext(ctx, GetDisputeEvidence, disputeID)
+	var i DisputeEvidence
+	err := row.Scan(
+		&i.DisputeID,
+		&i.Fields,
+		&i.UpdatedBy,
+		&i.SubmittedBy,
+		&i.SubmittedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const GetDocument = `-- name: GetDocument :one
+SELECT id, tenant_id, kind, source_id, language, object_key, checksum, size_bytes, created_at, updated_at FROM documents
+WHERE id = $1 AND tenant_id = $2 LIMIT 1
+`
+
+type GetDocumentParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenant_id"`
+}
+
+func (q *Queries) GetDocument(ctx context.Context, db DBTX, arg GetDocumentParams) (Document, error) {
+	row := db.QueryRowContext(ctx, GetDocument, arg.ID, arg.TenantID)
+	var i Document
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.Kind,
+		&i.SourceID,
+		&i.Language,
+		&i.ObjectKey,
+		&i.Checksum,
+		&i.SizeBytes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const GetDocumentBySource = `-- name: GetDocumentBySource :one
+SELECT id, tenant_id, kind, source_id, language, object_key, checksum, size_bytes, created_at, updated_at FROM documents
+WHERE tenant_id = $1 AND kind = $2 AND source_id = $3 AND language = $4 LIMIT 1
+`
+
+type GetDocumentBySourceParams struct {
+	TenantID string `json:"tenant_id"`
+	Kind     string `json:"kind"`
+	SourceID string `json:"source_id"`
+	Language string `json:"language"`
+}
+
+func (q *Queries) GetDocumentBySource(ctx context.Context, db DBTX, arg GetDocumentBySourceParams) (Document, error) {
+	row := db.QueryRowContext(ctx, GetDocumentBySource,
+		arg.TenantID,
+		arg.Kind,
+		arg.SourceID,
+		arg.Language,
+	)
+	var i Document
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.Kind,
+		&i.SourceID,
+		&i.Language,
+		&i.ObjectKey,
+		&i.Checksum,
+		&i.SizeBytes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const GetDocumentTemplate = `-- name: GetDocumentTemplate :one
+SELECT tenant_id, kind, business_name, address, tax_id, accent_color, footer, language, created_at, updated_at FROM document_templates
+WHERE tenant_id = $1 AND kind = $2 LIMIT 1
+`
+
+type GetDocumentTemplateParams struct {
+	TenantID string `json:"tenant_id"`
+	Kind     string `json:"kind"`
+}
+
+func (q *Queries) GetDocumentTemplate(ctx context.Context, db DBTX, arg GetDocumentTemplateParams) (DocumentTemplate, error) {
+	row := db.QueryRowContext(ctx, GetDocumentTemplate, arg.TenantID, arg.Kind)
+	var i DocumentTemplate
+	err := row.Scan(
+		&i.TenantID,
+		&i.Kind,
+		&i.BusinessName,
+		&i.Address,
+		&i.TaxID,
+		&i.AccentColor,
+		&i.Footer,
+		&i.Language,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const GetDunningCase = `-- name: GetDunningCase :one
+SELECT id, invoice_id, subscription_id, customer_id, amount_due, currency, state, attempts, next_retry_at, last_error, failed_at, resolved_at, created_at, updated_at, tenant_id FROM dunning_cases
+WHERE id = $1 AND ($2 = '' OR tenant_id = $2)
+`
+
+type GetDunningCaseParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenant_id"`
+}
+
+func (q *Queries) GetDunningCase(ctx context.Context, db DBTX, arg GetDunningCaseParams) (DunningCase, error) {
+	row := db.QueryRowContext(ctx, GetDunningCase, arg.ID, arg.TenantID)
+	var i DunningCase
+	err := row.Scan(
+		&i.ID,
+		&i.InvoiceID,
+		&i.SubscriptionID,
+		&i.CustomerID,
+		&i.AmountDue,
+		&i.Currency,
+		&i.State,
+		&i.Attempts,
+		&i.NextRetryAt,
+		&i.LastError,
+		&i.FailedAt,
+		&i.ResolvedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const GetDunningCaseByInvoice = `-- name: GetDunningCaseByInvoice :one
+SELECT id, invoice_id, subscription_id, customer_id, amount_due, currency, state, attempts, next_retry_at, last_error, failed_at, resolved_at, created_at, updated_at, tenant_id FROM dunning_cases
+WHERE invoice_id = $1
+`
+
+func (q *Queries) GetDunningCaseByInvoice(ctx context.Context, db DBTX, invoiceID string) (DunningCase, error) {
+	row := db.QueryRowContext(ctx, GetDunningCaseByInvoice, invoiceID)
+	var i DunningCase
+	err := row.Scan(
+		&i.ID,
+		&i.InvoiceID,
+		&i.SubscriptionID,
+		&i.CustomerID,
+		&i.AmountDue,
+		&i.Currency,
+		&i.State,
+		&i.Attempts,
+		&i.NextRetryAt,
+		&i.LastError,
+		&i.FailedAt,
+		&i.ResolvedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const GetEntityVersionAsOf = `-- name: GetEntityVersionAsOf :one
+SELECT id, entity_type, entity_id, status, state, event_id, event_type, valid_from, recorded_at FROM entity_versions
+WHERE entity_type = $1 AND entity_id = $2 AND valid_from <= $3
+ORDER BY valid_from DESC, id DESC
+LIMIT 1
+`
+
+type GetEntityVersionAsOfParams struct {
+	EntityType string    `json:"entity_type"`
+	EntityID   string    `json:"entity_id"`
+	ValidFrom  time.Time `json:"valid_from"`
+}
+
+func (q *Queries) GetEntityVersionAsOf(ctx context.Context, db DBTX, arg GetEntityVersionAsOfParams) (EntityVersion, error) {
+	row := db.QueryRowContext(ctx, GetEntityVersionAsOf, arg.EntityType, arg.EntityID, arg.ValidFrom)
+	var i EntityVersion
+	err := row.Scan(
+		&i.ID,
+		&i.EntityType,
+		&i.EntityID,
+		&i.Status,
+		&i.State,
+		&i.EventID,
+		&i.EventType,
+		&i.ValidFrom,
+		&i.RecordedAt,
+	)
+	return i, err
+}
+
+const GetEphemeralKeyBySecretHash = `-- name: GetEphemeralKeyBySecretHash :one
+SELECT id, customer_id, secret_hash, scopes, issued_by, expires_at, revoked_at, created_at FROM ephemeral_keys
+WHERE secret_hash = $1
+`
+
+func (q *Queries) GetEphemeralKeyBySecretHash(ctx context.Context, db DBTX, secretHash string) (EphemeralKey, error) {
+	row := db.QueryRowContext(ctx, GetEphemeralKeyBySecretHash, secretHash)
+	var i EphemeralKey
+	err := row.Scan(
+		&i.ID,
+		&i.CustomerID,
+		&i.SecretHash,
+		&i.Scopes,
+		&i.IssuedBy,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const GetFraudListEntry = `-- name: GetFraudListEntry :one
+SELECT id, list, entry_type, value, reason, created_by, created_at FROM fraud_list_entries
+WHERE id = $1
+`
+
+func (q *Queries) GetFraudListEntry(ctx context.Context, db DBTX, id string) (FraudListEntry, error) {
+	row := db.QueryRowContext(ctx, GetFraudListEntry, id)
+	var i FraudListEntry
+	err := row.Scan(
+		&i.ID,
+		&i.List,
+		&i.EntryType,
+		&i.Value,
+		&i.Reason,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const GetFraudReview = `-- name: GetFraudReview :one
+SELECT id, tenant_id, screening_id, customer_id, amount, currency, request, findings, status, decided_by, charge_id, failure_reason, decided_at, created_at, updated_at FROM fraud_reviews
+WHERE id = $1 AND ($2 = '' OR tenant_id = $2)
+`
+
+type GetFraudReviewParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenant_id"`
+}
+
+func (q *Queries) GetFraudReview(ctx context.Context, db DBTX, arg GetFraudReviewParams) (FraudReview, error) {
+	row := db.QueryRowContext(ctx, GetFraudReview, arg.ID, arg.TenantID)
+	var i FraudReview
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.ScreeningID,
+		&i.CustomerID,
+		&i.Amount,
+		&i.Currency,
+		&i.Request,
+		&i.Findings,
+		&i.Status,
+		&i.DecidedBy,
+		&i.ChargeID,
+		&i.FailureReason,
+		&i.DecidedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const GetHeldMutation = `-- name: GetHeldMutation :one
+SELECT id, quarantine_id, tenant_id, api_key_id, operator_id, method, path, content_type, body, status, decided_by, decided_at, response_status, response_body, created_at, updated_at FROM held_mutations
+WHERE id = $1
+`
+
+func (q *Queries) GetHeldMutation(ctx context.Context, db DBTX, id string) (HeldMutation, error) {
+	row := db.QueryRowContext(ctx, GetHeldMutation, id)
+	var i HeldMutation
+	err := row.Scan(
+		&i.ID,
+		&i.QuarantineID,
+		&i.TenantID,
+		&i.ApiKeyID,
+		&i.OperatorID,
+		&i.Method,
+		&i.Path,
+		&i.ContentType,
+		&i.Body,
+		&i.Status,
+		&i.DecidedBy,
+		&i.DecidedAt,
+		&i.ResponseStatus,
+		&i.ResponseBody,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const GetHoldPolicy = `-- name: GetHoldPolicy :one
+SELECT tenant_id, pause_on_dispute, pause_on_fraud, block_charges_on_dispute, block_charges_on_fraud, min_risk_score, auto_release, created_at, updated_at FROM hold_policies
+WHERE tenant_id = $1 LIMIT 1
+`
+
+func (q *Queries) GetHoldPolicy(ctx context.Context, db DBTX, tenantID string) (HoldPolicy, error) {
+	row := db.QueryRowContext(ctx, GetHoldPolicy, tenantID)
+	var i HoldPolicy
+	err := row.Scan(
+		&i.TenantID,
+		&i.PauseOnDispute,
+		&i.PauseOnFraud,
+		&i.BlockChargesOnDispute,
+		&i.BlockChargesOnFraud,
+		&i.MinRiskScore,
+		&i.AutoRelease,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const GetInvoice = `-- name: GetInvoice :one
+SELECT id, customer_id, subscription_id, number, status, collection_method, description, amount_due, amount_paid, amount_remaining, currency, due_date, paid_out_of_band, hosted_invoice_url, invoice_pdf, custom_fields, metadata, invoiced_at, synced_at, created_at, updated_at, customer_name, customer_email, subtotal, tax, total, lines, tax_amounts, tenant_id FROM invoices
+WHERE id = $1 AND ($2 = '' OR tenant_id = $2)
+`
+
+type GetInvoiceParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenant_id"`
+}
+
+func (q *Queries) GetInvoice(ctx context.Context, db DBTX, arg GetInvoiceParams) (Invoice, error) {
+	row := db.QueryRowContext(ctx, GetInvoice, arg.ID, arg.TenantID)
+	var i Invoice
+	err := row.Scan(
+		&i.ID,
+		&i.CustomerID,
+		&i.SubscriptionID,
+		&i.Number,
+		&i.Status,
+		&i.CollectionMethod,
+		&i.Description,
+		&i.AmountDue,
+		&i.AmountPaid,
+		&i.AmountRemaining,
+		&i.Currency,
+		&i.DueDate,
+		&i.PaidOutOfBand,
+		&i.HostedInvoiceUrl,
+		&i.InvoicePdf,
+		&i.CustomFields,
+		&i.Metadata,
+		&i.InvoicedAt,
+		&i.SyncedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CustomerName,
+		&i.CustomerEmail,
+		&i.Subtotal,
+		&i.Tax,
+		&i.Total,
+		&i.Lines,
+		&i.TaxAmounts,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const GetLastWebhookEventTime = `-- name: GetLastWebhookEventTime :one
+SELECT COALESCE(MAX(created), 0)::bigint AS last_created
+FROM webhook_events
+`
+
+func (q *Queries) GetLastWebhookEventTime(ctx context.Context, db DBTX) (int64, error) {
+	row := db.QueryRowContext(ctx, GetLastWebhookEventTime)
+	var last_created int64
+	err := row.Scan(&last_created)
+	return last_created, err
+}
+
+const GetLatestAuditEntry = `-- name: GetLatestAuditEntry :one
+SELECT sequence, tenant_id, actor_type, actor_id, operator_id, action, resource_type, resource_id, method, path, status, before_state, after_state, changes, ip, request_id, created_at, previous_hash, hash FROM audit_log
+ORDER BY sequence DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLatestAuditEntry(ctx context.Context, db DBTX) (AuditLog, error) {
+	row := db.QueryRowContext(ctx, GetLatestAuditEntry)
+	var i AuditLog
+	err := row.Scan(
+		&i.Sequence,
+		&i.TenantID,
+		&i.ActorType,
+		&i.ActorID,
+		&i.OperatorID,
+		&i.Action,
+		&i.ResourceType,
+		&i.ResourceID,
+		&i.Method,
+		&i.Path,
+		&i.Status,
+		&i.BeforeState,
+		&i.AfterState,
+		&i.Changes,
+		&i.Ip,
+		&i.RequestID,
+		&i.CreatedAt,
+		&i.PreviousHash,
+		&i.Hash,
+	)
+	return i, err
+}
+
+const GetLatestChargeTransition = `-- name: GetLatestChargeTransition :one
+SELECT id, charge_id, sequence, from_state, to_state, source, event_id, created_at FROM charge_transitions
+WHERE charge_id = $1
+ORDER BY sequence DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLatestChargeTransition(ctx context.Context, db DBTX, chargeID string) (ChargeTransition, error) {
+	row := db.QueryRowContext(ctx, GetLatestChargeTransition, chargeID)
+	var i ChargeTransition
+	err := row.Scan(
+		&i.ID,
+		&i.ChargeID,
+		&i.Sequence,
+		&i.FromState,
+		&i.ToState,
+		&i.Source,
+		&i.EventID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const GetLatestCompletedWebhookSecretRotation = `-- name: GetLatestCompletedWebhookSecretRotation :one
+SELECT id, status, old_endpoint_id, new_endpoint_id, new_secret, deliveries, confirmed_at, grace_ends_at, completed_at, cancelled_at, created_at, updated_at FROM webhook_secret_rotations
+WHERE status = 'completed'
+ORDER BY completed_at DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLatestCompletedWebhookSecretRotation(ctx context.Context, db DBTX) (WebhookSecretRotation, error) {
+	row := db.QueryRowContext(ctx, GetLatestCompletedWebhookSecretRotation)
+	var i WebhookSecretRotation
+	err := row.Scan(
+		&i.ID,
+		&i.Status,
+		&i.OldEndpointID,
+		&i.NewEndpointID,
+		&i.NewSecret,
+		&i.Deliveries,
+		&i.ConfirmedAt,
+		&i.GraceEndsAt,
+		&i.CompletedAt,
+		&i.CancelledAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const GetLatestRadarScore = `-- name: GetLatestRadarScore :one
+SELECT charge_id, customer_id, card_fingerprint, risk_score, risk_level, created_at FROM fraud_radar_scores
+WHERE (($1::text <> '' AND customer_id = $1)
+    OR ($2::text <> '' AND card_fingerprint = $2))
+  AND created_at >= $3
+ORDER BY created_at DESC
+LIMIT 1
+`
+
+type GetLatestRadarScoreParams struct {
+	CustomerID      string       `json:"customer_id"`
+	CardFingerprint string       `json:"card_fingerprint"`
+	Since           sql.NullTime `json:"since"`
+}
+
+func (q *Queries) GetLatestRadarScore(ctx context.Context, db DBTX, arg GetLatestRadarScoreParams) (FraudRadarScore, error) {
+	row := db.QueryRowContext(ctx, GetLatestRadarScore, arg.CustomerID, arg.CardFingerprint, arg.Since)
+	var i FraudRadarScore
+	err := row.Scan(
+		&i.ChargeID,
+		&i.CustomerID,
+		&i.CardFingerprint,
+		&i.RiskScore,
+		&i.RiskLevel,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const GetLatestResourceAuditEntry = `-- name: GetLatestResourceAuditEntry :one
+SELECT sequence, tenant_id, actor_type, actor_id, operator_id, action, resource_type, resource_id, method, path, status, before_state, after_state, changes, ip, request_id, created_at, previous_hash, hash FROM audit_log
+WHERE resource_type = $1 AND resource_id = $2
+ORDER BY sequence DESC
+LIMIT 1
+`
+
+type GetLatestResourceAuditEntryParams struct {
+	ResourceType string `json:"resource_type"`
+	ResourceID   string `json:"resource_id"`
+}
+
+func (q *Queries) GetLatestResourceAuditEntry(ctx context.Context, db DBTX, arg GetLatestResourceAuditEntryParams) (AuditLog, error) {
+	row := db.QueryRowContext(ctx, GetLatestResourceAuditEntry, arg.ResourceType, arg.ResourceID)
+	var i AuditLog
+	err := row.Scan(
+		&i.Sequence,
+		&i.TenantID,
+		&i.ActorType,
+		&i.ActorID,
+		&i.OperatorID,
+		&i.Action,
+		&i.ResourceType,
+		&i.ResourceID,
+		&i.Method,
+		&i.Path,
+		&i.Status,
+		&i.BeforeState,
+		&i.AfterState,
+		&i.Changes,
+		&i.Ip,
+		&i.RequestID,
+		&i.CreatedAt,
+		&i.PreviousHash,
+		&i.Hash,
+	)
+	return i, err
+}
+
+const GetMetadataSchema = `-- name: GetMetadataSchema :one
+SELECT tenant_id, resource, schema, created_at, updated_at FROM metadata_schemas
+WHERE tenant_id = $1 AND resource = $2 LIMIT 1
+`
+
+type GetMetadataSchemaParams struct {
+	TenantID string `json:"tenant_id"`
+	Resource string `json:"resource"`
+}
+
+func (q *Queries) GetMetadataSchema(ctx context.Context, db DBTX, arg GetMetadataSchemaParams) (MetadataSchema, error) {
+	row := db.QueryRowContext(ctx, GetMetadataSchema, arg.TenantID, arg.Resource)
+	var i MetadataSchema
+	err := row.Scan(
+		&i.TenantID,
+		&i.Resource,
+		&i.Schema,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const GetOffboardingArchive = `-- name: GetOffboardingArchive :one
+SELECT archive FROM offboarding_archives
+WHERE export_id = $1
+`
+
+func (q *Queries) GetOffboardingArchive(ctx context.Context, db DBTX, exportID string) ([]byte, error) {
+	row := db.QueryRowContext(ctx, GetOffboardingArchive, exportID)
+	var archive []byte
+	err := row.Scan(&archive)
+	return archive, err
+}
+
+const GetOffboardingExport = `-- name: GetOffboardingExport :one
+SELECT id, tenant_id, status, recipient_public_key, manifest, archive_sha256, archive_size, error, pan_migration_status, pan_migration_destination, pan_migration_reference, pan_migration_requested_at, started_at, completed_at, created_at, updated_at FROM offboarding_exports
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetOffboardingExport(ctx context.Context, db DBTX, id string) (OffboardingExport, error) {
+	row := db.QueryRowContext(ctx, GetOffboardingExport, id)
+	var i OffboardingExport
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.Status,
+		&i.RecipientPublicKey,
+		&i.Manifest,
+		&i.ArchiveSha256,
+		&i.ArchiveSize,
+		&i.Error,
+		&i.PanMigrationStatus,
+		&i.PanMigrationDestination,
+		&i.PanMigrationReference,
+		&i.PanMigrationRequestedAt,
+		&i.StartedAt,
+		&i.CompletedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const GetPaymentLink = `-- name: GetPaymentLink :one
+SELECT id, url, amount, currency, product_name, price_id, active, expires_at, max_uses, conversions, amount_collected, metadata, deactivated_at, created_at, updated_at, tenant_id FROM payment_links
+WHERE id = $1 AND ($2 = '' OR tenant_id = $2)
+`
+
+type GetPaymentLinkParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenant_id"`
+}
+
+func (q *Queries) GetPaymentLink(ctx context.Context, db DBTX, arg GetPaymentLinkParams) (PaymentLink, error) {
+	row := db.QueryRowContext(ctx, GetPaymentLink, arg.ID, arg.TenantID)
+	var i PaymentLink
+	err := row.Scan(
+		&i.ID,
+		&i.Url,
+		&i.Amount,
+		&i.Currency,
+		&i.ProductName,
+		&i.PriceID,
+		&i.Active,
+		&i.ExpiresAt,
+		&i.MaxUses,
+		&i.Conversions,
+		&i.AmountCollected,
+		&i.Metadata,
+		&i.DeactivatedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const GetPaymentMethod = `-- name: GetPaymentMethod :one
+SELECT id, type, customer_id, card_last4, card_brand, card_exp_month, card_exp_year, card_fingerprint, metadata, created_at, synced_at, tenant_id FROM payment_methods
+WHERE id = $1 AND ($2 = '' OR tenant_id = $2) LIMIT 1
+`
+
+type GetPaymentMethodParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenant_id"`
+}
+
+func (q *Queries) GetPaymentMethod(ctx context.Context, db DBTX, arg GetPaymentMethodParams) (PaymentMethod, error) {
+	row := db.QueryRowContext(ctx, GetPaymentMethod, arg.ID, arg.TenantID)
+	var i PaymentMethod
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.CustomerID,
+		&i.CardLast4,
+		&i.CardBrand,
+		&i.CardExpMonth,
+		&i.CardExpYear,
+		&i.CardFingerprint,
+		&i.Metadata,
+		&i.CreatedAt,
+		&i.SyncedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const GetPaymentMethodPreference = `-- name: GetPaymentMethodPreference :one
+SELECT tenant_id, customer_id, default_payment_method, backup_payment_methods, created_at, updated_at FROM payment_method_preferences
+WHERE tenant_id = $1 AND customer_id = $2 LIMIT 1
+`
+
+type GetPaymentMethodPreferenceParams struct {
+	TenantID   string `json:"tenant_id"`
+	CustomerID string `json:"customer_id"`
+}
+
+func (q *Queries) GetPaymentMethodPreference(ctx context.Context, db DBTX, arg GetPaymentMethodPreferenceParams) (PaymentMethodPreference, error) {
+	row := db.QueryRowContext(ctx, GetPaymentMethodPreference, arg.TenantID, arg.CustomerID)
+	var i PaymentMethodPreference
+	err := row.Scan(
+		&i.TenantID,
+		&i.CustomerID,
+		&i.DefaultPaymentMethod,
+		&i.BackupPaymentMethods,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const GetPendingWebhookSecretRotation = `-- name: GetPendingWebhookSecretRotation :one
+SELECT id, status, old_endpoint_id, new_endpoint_id, new_secret, deliveries, confirmed_at, grace_ends_at, completed_at, cancelled_at, created_at, updated_at FROM webhook_secret_rotations
+WHERE status = 'pending' LIMIT 1
+`
+
+func (q *Queries) GetPendingWebhookSecretRotation(ctx context.Context, db DBTX) (WebhookSecretRotation, error) {
+	row := db.QueryRowContext(ctx, GetPendingWebhookSecretRotation)
+	var i WebhookSecretRotation
+	err := row.Scan(
+		&i.ID,
+		&i.Status,
+		&i.OldEndpointID,
+		&i.NewEndpointID,
+		&i.NewSecret,
+		&i.Deliveries,
+		&i.ConfirmedAt,
+		&i.GraceEndsAt,
+		&i.CompletedAt,
+		&i.CancelledAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const GetProviderCredential = `-- name: GetProviderCredential :one
+SELECT tenant_id, provider, ciphertext, masked_fields, version, verified_at, created_at, updated_at FROM provider_credentials
+WHERE tenant_id = $1 AND provider = $2 LIMIT 1
+`
+
+type GetProviderCredentialParams struct {
+	TenantID string `json:"tenant_id"`
+	Provider string `json:"provider"`
+}
+
+func (q *Queries) GetProviderCredential(ctx context.Context, db DBTX, arg GetProviderCredentialParams) (ProviderCredential, error) {
+	row := db.QueryRowContext(ctx, GetProviderCredential, arg.TenantID, arg.Provider)
+	var i ProviderCredential
+	err := row.Scan(
+		&i.TenantID,
+		&i.Provider,
+		&i.Ciphertext,
+		&i.MaskedFields,
+		&i.Version,
+		&i.VerifiedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const GetQuarantine = `-- name: GetQuarantine :one
+SELECT id, subject_type, subject_id, reason, created_by, lifted_by, lifted_at, created_at, updated_at FROM quarantines
+WHERE id = $1
+`
+
+func (q *Queries) GetQuarantine(ctx context.Context, db DBTX, id string) (Quarantine, error) {
+	row := db.QueryRowContext(ctx, GetQuarantine, id)
+	var i Quarantine
+	err := row.Scan(
+		&i.ID,
+		&i.SubjectType,
+		&i.SubjectID,
+		&i.Reason,
+		&i.CreatedBy,
+		&i.LiftedBy,
+		&i.LiftedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const GetReceiptSettings = `-- name: GetReceiptSettings :one
+SELECT tenant_id, send_receipt_emails, descriptor_suffix, business_name, support_email, created_at, updated_at FROM receipt_settings
+WHERE tenant_id = $1 LIMIT 1
+`
+
+func (q *Queries) GetReceiptSettings(ctx context.Context, db DBTX, tenantID string) (ReceiptSetting, error) {
+	row := db.QueryRowContext(ctx, GetReceiptSettings, tenantID)
+	var i ReceiptSetting
+	err := row.Scan(
+		&i.TenantID,
+		&i.SendReceiptEmails,
+		&i.DescriptorSuffix,
+		&i.BusinessName,
+		&i.SupportEmail,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const GetReceivableInvoice = `-- name: GetReceivableInvoice :one
+SELECT invoice_id, customer_id, subscription_id, number, amount_due, amount_remaining, currency, due_date, status, overdue_at, paid_at, paid_reference, created_at, updated_at FROM receivable_invoices
+WHERE invoice_id = $1 LIMIT 1
+`
+
+func (q *Queries) GetReceivableInvoice(ctx context.Context, db DBTX, invoiceID string) (ReceivableInvoice, error) {
+	row := db.QueryRowContext(ctx, GetReceivableInvoice, invoiceID)
+	var i ReceivableInvoice
+	err := row.Scan(
+		&i.InvoiceID,
+		&i.CustomerID,
+		&i.SubscriptionID,
+		&i.Number,
+		&i.AmountDue,
+		&i.AmountRemaining,
+		&i.Currency,
+		&i.DueDate,
+		&i.Status,
+		&i.OverdueAt,
+		&i.PaidAt,
+		&i.PaidReference,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const GetReconciliationRun = `-- name: GetReconciliationRun :one
+SELECT id, status, period_start, period_end, transactions_checked, payouts_checked, matched, mismatches, error, started_at, completed_at FROM reconciliation_runs
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetReconciliationRun(ctx context.Context, db DBTX, id string) (ReconciliationRun, error) {
+	row := db.QueryRowContext(ctx, GetReconciliationRun, id)
+	var i ReconciliationRun
+	err := row.Scan(
+		&i.ID,
+		&i.Status,
+		&i.PeriodStart,
+		&i.PeriodEnd,
+		&i.TransactionsChecked,
+		&i.PayoutsChecked,
+		&i.Matched,
+		&i.Mismatches,
+		&i.Error,
+		&i.StartedAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
+
+const GetRefund = `-- name: GetRefund :one
+SELECT id, charge_id, amount, currency, status, reason, metadata, created_at, updated_at, synced_at, tenant_id FROM refunds
+WHERE id = $1 AND ($2 = '' OR tenant_id = $2) LIMIT 1
+`
+
+type GetRefundParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenant_id"`
+}
+
+func (q *Queries) GetRefund(ctx context.Context, db DBTX, arg GetRefundParams) (Refund, error) {
+	row := db.QueryRowContext(ctx, GetRefund, arg.ID, arg.TenantID)
+	var i Refund
+	err := row.Scan(
+		&i.ID,
+		&i.ChargeID,
+		&i.Amount,
+		&i.Currency,
+		&i.Status,
+		&i.Reason,
+		&i.Metadata,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SyncedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const GetRefundApproval = `-- name: GetRefundApproval :one
+SELECT id, tenant_id, api_key_id, operator_id, request, amount, reasons, status, decided_by, refund_id, created_at, updated_at, decided_at FROM refund_approvals
+WHERE id = $1 AND ($2 = '' OR tenant_id = $2) LIMIT 1
+`
+
+type GetRefundApprovalParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenant_id"`
+}
+
+func (q *Queries) GetRefundApproval(ctx context.Context, db DBTX, arg GetRefundApprovalParams) (RefundApproval, error) {
+	row := db.QueryRowContext(ctx, GetRefundApproval, arg.ID, arg.TenantID)
+	var i RefundApproval
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.ApiKeyID,
+		&i.OperatorID,
+		&i.Request,
+		&i.Amount,
+		&i.Reasons,
+		&i.Status,
+		&i.DecidedBy,
+		&i.RefundID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DecidedAt,
+	)
+	return i, err
+}
+
+const GetRefundBatch = `-- name: GetRefundBatch :one
+SELECT id, tenant_id, api_key_id, operator_id, requests, status, scheduled_at, started_at, completed_at, created_at, updated_at FROM refund_batches
+WHERE id = $1 AND ($2 = '' OR tenant_id = $2) LIMIT 1
+`
+
+type GetRefundBatchParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenant_id"`
+}
+
+func (q *Queries) GetRefundBatch(ctx context.Context, db DBTX, arg GetRefundBatchParams) (RefundBatch, error) {
+	row := db.QueryRowContext(ctx, GetRefundBatch, arg.ID, arg.TenantID)
+	var i RefundBatch
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.ApiKeyID,
+		&i.OperatorID,
+		&i.Requests,
+		&i.Status,
+		&i.ScheduledAt,
+		&i.StartedAt,
+		&i.CompletedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const GetRefundStats = `-- name: GetRefundStats :one
+SELECT 
+    COUNT(*) as total_refunds,
+    SUM(amount) as total_amount,
+    COUNT(CASE WHEN status = 'succeeded' THEN 1 END) as successful_refunds,
+    SUM(CASE WHEN status = 'succeeded' THEN amount ELSE 0 END) as successful_amount
+FROM refunds
+`
+
+type GetRefundStatsRow struct {
+	TotalRefunds      int64 `json:"total_refunds"`
+	TotalAmount       int64 `json:"total_amount"`
+	SuccessfulRefunds int64 `json:"successful_refunds"`
+	SuccessfulAmount  int64 `json:"successful_amount"`
+}
+
+func (q *Queries) GetRefundStats(ctx context.Context, db DBTX) (GetRefundStatsRow, error) {
+	row := db.QueryRowContext(ctx, GetRefundStats)
+	var i GetRefundStatsRow
+	err := row.Scan(
+		&i.TotalRefunds,
+		&i.TotalAmount,
+		&i.SuccessfulRefunds,
+		&i.SuccessfulAmount,
+	)
+	return i, err
+}
+
+const GetRefundUsageByAPIKey = `-- name: GetRefundUsageByAPIKey :one
+SELECT COUNT(*) AS refund_count, COALESCE(SUM(amount), 0)::bigint AS refund_amount
+FROM refund_activity
+WHERE api_key_id = $1 AND created_at >= $2
+`
+
+type GetRefundUsageByAPIKeyParams struct {
+	ApiKeyID  string       `json:"api_key_id"`
+	CreatedAt sql.NullTime `json:"created_at"`
+}
+
+type GetRefundUsageByAPIKeyRow struct {
+	RefundCount  int64 `json:"refund_count"`
+	RefundAmount int64 `json:"refund_amount"`
+}
+
+func (q *Queries) GetRefundUsageByAPIKey(ctx context.Context, db DBTX, arg GetRefundUsageByAPIKeyParams) (GetRefundUsageByAPIKeyRow, error) {
+	row := db.QueryRowContext(ctx, GetRefundUsageByAPIKey, arg.ApiKeyID, arg.CreatedAt)
+	var i GetRefundUsageByAPIKeyRow
+	err := row.Scan(&i.RefundCount, &i.RefundAmount)
+	return i, err
+}
+
+const GetRefundUsageByOperator = `-- name: GetRefundUsageByOperator :one
+SELECT COUNT(*) AS refund_count, COALESCE(SUM(amount), 0)::bigint AS refund_amount
+FROM refund_activity
+WHERE operator_id = $1 AND created_at >= $2
+`
+
+type GetRefundUsageByOperatorParams struct {
+	OperatorID string       `json:"operator_id"`
+	CreatedAt  sql.NullTime `json:"created_at"`
+}
+
+type GetRefundUsageByOperatorRow struct {
+	RefundCount  int64 `json:"refund_count"`
+	RefundAmount int64 `json:"refund_amount"`
+}
+
+func (q *Queries) GetRefundUsageByOperator(ctx context.Context, db DBTX, arg GetRefundUsageByOperatorParams) (GetRefundUsageByOperatorRow, error) {
+	row := db.QueryRowContext(ctx, GetRefundUsageByOperator, arg.OperatorID, arg.CreatedAt)
+	var i GetRefundUsageByOperatorRow
+	err := row.Scan(&i.RefundCount, &i.RefundAmount)
+	return i, err
+}
+
+const GetRefundUsageByTenant = `-- name: GetRefundUsageByTenant :one
+SELECT COUNT(*) AS refund_count, COALESCE(SUM(amount), 0)::bigint AS refund_amount
+FROM refund_activity
+WHERE tenant_id = $1 AND created_at >= $2
+`
+
+type GetRefundUsageByTenantParams struct {
+	TenantID  string       `json:"tenant_id"`
+	CreatedAt sql.NullTime `json:"created_at"`
+}
+
+type GetRefundUsageByTenantRow struct {
+	RefundCount  int64 `json:"refund_count"`
+	RefundAmount int64 `json:"refund_amount"`
+}
+
+func (q *Queries) GetRefundUsageByTenant(ctx context.Context, db DBTX, arg GetRefundUsageByTenantParams) (GetRefundUsageByTenantRow, error) {
+	row := db.QueryRowContext(ctx, GetRefundUsageByTenant, arg.TenantID, arg.CreatedAt)
+	var i GetRefundUsageByTenantRow
+	err := row.Scan(&i.RefundCount, &i.RefundAmount)
+	return i, err
+}
+
+const GetSimulatorObject = `-- name: GetSimulatorObject :one
+SELECT tenant_id, id, kind, parent_id, data, created_at, updated_at FROM simulator_objects
+WHERE tenant_id = $1 AND kind = $2 AND id = $3
+`
+
+type GetSimulatorObjectParams struct {
+	TenantID string `json:"tenant_id"`
+	Kind     string `json:"kind"`
+	ID       string `json:"id"`
+}
+
+func (q *Queries) GetSimulatorObject(ctx context.Context, db DBTX, arg GetSimulatorObjectParams) (SimulatorObject, error) {
+	row := db.QueryRowContext(ctx, GetSimulatorObject, arg.TenantID, arg.Kind, arg.ID)
+	var i SimulatorObject
+	err := row.Scan(
+		&i.TenantID,
+		&i.ID,
+		&i.Kind,
+		&i.ParentID,
+		&i.Data,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const GetSmartRoutingRule = `-- name: GetSmartRoutingRule :one
+SELECT id, currency, bin_country, min_amount, max_amount, strategy, providers, pinned_provider, priority, enabled, description, updated_by, created_at, updated_at FROM smart_routing_rules
+WHERE id = $1
+`
+
+func (q *Queries) GetSmartRoutingRule(ctx context.Context, db DBTX, id string) (SmartRoutingRule, error) {
+	row := db.QueryRowContext(ctx, GetSmartRoutingRule, id)
+	var i SmartRoutingRule
+	err := row.Scan(
+		&i.ID,
+		&i.Currency,
+		&i.BinCountry,
+		&i.MinAmount,
+		&i.MaxAmount,
+		&i.Strategy,
+		&i.Providers,
+		&i.PinnedProvider,
+		&i.Priority,
+		&i.Enabled,
+		&i.Description,
+		&i.UpdatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const GetSubscription = `-- name: GetSubscription :one
+SELECT id, customer_id, plan_id, status, paused, cancel_at_period_end, current_period_start, current_period_end, collection_method, days_until_due, metadata, subscription_created_at, synced_at, created_at, updated_at, tenant_id FROM subscriptions
+WHERE id = $1 AND ($2 = '' OR tenant_id = $2)
+`
+
+type GetSubscriptionParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenant_id"`
+}
+
+func (q *Queries) GetSubscription(ctx context.Context, db DBTX, arg GetSubscriptionParams) (Subscription, error) {
+	row := db.QueryRowContext(ctx, GetSubscription, arg.ID, arg.TenantID)
+	var i Subscription
+	err := row.Scan(
+		&i.ID,
+		&i.CustomerID,
+		&i.PlanID,
+		&i.Status,
+		&i.Paused,
+		&i.CancelAtPeriodEnd,
+		&i.CurrentPeriodStart,
+		&i.CurrentPeriodEnd,
+		&i.CollectionMethod,
+		&i.DaysUntilDue,
+		&i.Metadata,
+		&i.SubscriptionCreatedAt,
+		&i.SyncedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const GetSubscriptionPlan = `-- name: GetSubscriptionPlan :one
+SELECT id, product_id, nickname, amount, currency, billing_interval, interval_count, active, metadata, plan_created_at, synced_at, created_at, updated_at FROM subscription_plans
+WHERE id = $1
+`
+
+func (q *Queries) GetSubscriptionPlan(ctx context.Context, db DBTX, id string) (SubscriptionPlan, error) {
+	row := db.QueryRowContext(ctx, GetSubscriptionPlan, id)
+	var i SubscriptionPlan
+	err := row.Scan(
+		&i.ID,
+		&i.ProductID,
+		&i.Nickname,
+		&i.Amount,
+		&i.Currency,
+		&i.BillingInterval,
+		&i.IntervalCount,
+		&i.Active,
+		&i.Metadata,
+		&i.PlanCreatedAt,
+		&i.SyncedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const GetTenantBudget = `-- name: GetTenantBudget :one
+SELECT tenant_id, monthly_limit, thresholds, hard_stop, updated_by, created_at, updated_at FROM tenant_budgets
+WHERE tenant_id = $1
+`
+
+func (q *Queries) GetTenantBudget(ctx context.Context, db DBTX, tenantID string) (TenantBudget, error) {
+	row := db.QueryRowContext(ctx, GetTenantBudget, tenantID)
+	var i TenantBudget
+	err := row.Scan(
+		&i.TenantID,
+		&i.MonthlyLimit,
+		&i.Thresholds,
+		&i.HardStop,
+		&i.UpdatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const GetTenantConfig = `-- name: GetTenantConfig :one
+SELECT tenant_id, name, status, default_provider, created_at, updated_at FROM tenant_configs
+WHERE tenant_id = $1
+`
+
+func (q *Queries) GetTenantConfig(ctx context.Context, db DBTX, tenantID string) (TenantConfig, error) {
+	row := db.QueryRowContext(ctx, GetTenantConfig, tenantID)
+	var i TenantConfig
+	err := row.Scan(
+		&i.TenantID,
+		&i.Name,
+		&i.Status,
+		&i.DefaultProvider,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const GetTenantSpend = `-- name: GetTenantSpend :one
+SELECT tenant_id, period, amount, charge_count FROM tenant_spend
+WHERE tenant_id = $1 AND period = $2
+`
+
+type GetTenantSpendParams struct {
+	TenantID string `json:"tenant_id"`
+	Period   string `json:"period"`
+}
+
+func (q *Queries) GetTenantSpend(ctx context.Context, db DBTX, arg GetTenantSpendParams) (TenantSpend, error) {
+	row := db.QueryRowContext(ctx, GetTenantSpend, arg.TenantID, arg.Period)
+	var i TenantSpend
+	err := row.Scan(
+		&i.TenantID,
+		&i.Period,
+		&i.Amount,
+		&i.ChargeCount,
+	)
+	return i, err
+}
+
+const GetUnclaimedBalance = `-- name: GetUnclaimedBalance :one
+SELECT customer_id, currency, amount, funded_at, created_at, updated_at FROM unclaimed_balances
+WHERE customer_id = $1 AND currency = $2 LIMIT 1
+`
+
+type GetUnclaimedBalanceParams struct {
+	CustomerID string `json:"customer_id"`
+	Currency   string `json:"currency"`
+}
+
+func (q *Queries) GetUnclaimedBalance(ctx context.Context, db DBTX, arg GetUnclaimedBalanceParams) (UnclaimedBalance, error) {
+	row := db.QueryRowContext(ctx, GetUnclaimedBalance, arg.CustomerID, arg.Currency)
+	var i UnclaimedBalance
+	err := row.Scan(
+		&i.CustomerID,
+		&i.Currency,
+		&i.Amount,
+		&i.FundedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const GetUsageRecord = `-- name: GetUsageRecord :one
+SELECT id, subscription_id, subscription_item_id, price_id, quantity, action, recorded_at, idempotency_key, provider_record_id, reported_at, attempts, last_error, created_at, updated_at FROM usage_records
+WHERE id = $1
+`
+
+func (q *Queries) GetUsageRecord(ctx context.Context, db DBTX, id string) (UsageRecord, error) {
+	row := db.QueryRowContext(ctx, GetUsageRecord, id)
+	var i UsageRecord
+	err := row.Scan(
+		&i.ID,
+		&i.SubscriptionID,
+		&i.SubscriptionItemID,
+		&i.PriceID,
+		&i.Quantity,
+		&i.Action,
+		&i.RecordedAt,
+		&i.IdempotencyKey,
+		&i.ProviderRecordID,
+		&i.ReportedAt,
+		&i.Attempts,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const GetUsageRecordByKey = `-- name: GetUsageRecordByKey :one
+SELECT id, subscription_id, subscription_item_id, price_id, quantity, action, recorded_at, idempotency_key, provider_record_id, reported_at, attempts, last_error, created_at, updated_at FROM usage_records
+WHERE subscription_id = $1 AND idempotency_key = $2
+`
+
+type GetUsageRecordByKeyParams struct {
+	SubscriptionID string `json:"subscription_id"`
+	IdempotencyKey string `json:"idempotency_key"`
+}
+
+func (q *Queries) GetUsageRecordByKey(ctx context.Context, db DBTX, arg GetUsageRecordByKeyParams) (UsageRecord, error) {
+	row := db.QueryRowContext(ctx, GetUsageRecordByKey, arg.SubscriptionID, arg.IdempotencyKey)
+	var i UsageRecord
+	err := row.Scan(
+		&i.ID,
+		&i.SubscriptionID,
+		&i.SubscriptionItemID,
+		&i.PriceID,
+		&i.Quantity,
+		&i.Action,
+		&i.RecordedAt,
+		&i.IdempotencyKey,
+		&i.ProviderRecordID,
+		&i.ReportedAt,
+		&i.Attempts,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const GetVaultToken = `-- name: GetVaultToken :one
+SELECT id, provider, provider_token, customer_id, type, fingerprint, created_at, updated_at, tenant_id FROM vault_tokens
+WHERE id = $1 AND ($2 = '' OR tenant_id = $2)
+`
+
+type GetVaultTokenParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenant_id"`
+}
+
+func (q *Queries) GetVaultToken(ctx context.Context, db DBTX, arg GetVaultTokenParams) (VaultToken, error) {
+	row := db.QueryRowContext(ctx, GetVaultToken, arg.ID, arg.TenantID)
+	var i VaultToken
+	err := row.Scan(
+		&i.ID,
+		&i.Provider,
+		&i.ProviderToken,
+		&i.CustomerID,
+		&i.Type,
+		&i.Fingerprint,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const GetVaultTokenByProviderToken = `-- name: GetVaultTokenByProviderToken :one
+SELECT id, provider, provider_token, customer_id, type, fingerprint, created_at, updated_at, tenant_id FROM vault_tokens
+WHERE provider = $1 AND provider_token = $2
+`
+
+type GetVaultTokenByProviderTokenParams struct {
+	Provider      string `json:"provider"`
+	ProviderToken string `json:"provider_token"`
+}
+
+func (q *Queries) GetVaultTokenByProviderToken(ctx context.Context, db DBTX, arg GetVaultTokenByProviderTokenParams) (VaultToken, error) {
+	row := db.QueryRowContext(ctx, GetVaultTokenByProviderToken, arg.Provider, arg.ProviderToken)
+	var i VaultToken
+	err := row.Scan(
+		&i.ID,
+		&i.Provider,
+		&i.ProviderToken,
+		&i.CustomerID,
+		&i.Type,
+		&i.Fingerprint,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const GetWebhookDelivery = `-- name: GetWebhookDelivery :one
+SELECT id, tenant_id, endpoint_id, event_id, event_type, payload, status, attempts, last_status_code, last_error, next_attempt_at, delivered_at, created_at, updated_at FROM webhook_deliveries
+WHERE tenant_id = $1 AND id = $2 LIMIT 1
+`
+
+type GetWebhookDeliveryParams struct {
+	TenantID string `json:"tenant_id"`
+	ID       string `json:"id"`
+}
+
+func (q *Queries) GetWebhookDelivery(ctx context.Context, db DBTX, arg GetWebhookDeliveryParams) (WebhookDelivery, error) {
+	row := db.QueryRowContext(ctx, GetWebhookDelivery, arg.TenantID, arg.ID)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.EndpointID,
+		&i.EventID,
+		&i.EventType,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.LastStatusCode,
+		&i.LastError,
+		&i.NextAttemptAt,
+		&i.DeliveredAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const GetWebhookEndpoint = `-- name: GetWebhookEndpoint :one
+SELECT id, tenant_id, url, description, event_types, secret_ciphertext, enabled, created_at, updated_at FROM webhook_endpoints
+WHERE tenant_id = $1 AND id = $2 LIMIT 1
+`
+
+type GetWebhookEndpointParams struct {
+	TenantID string `json:"tenant_id"`
+	ID       string `json:"id"`
+}
+
+func (q *Queries) GetWebhookEndpoint(ctx context.Context, db DBTX, arg GetWebhookEndpointParams) (WebhookEndpoint, error) {
+	row := db.QueryRowContext(ctx, GetWebhookEndpoint, arg.TenantID, arg.ID)
+	var i WebhookEndpoint
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.Url,
+		&i.Description,
+		&i.EventTypes,
+		&i.SecretCiphertext,
+		&i.Enabled,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const GetWebhookEvent = `-- name: GetWebhookEvent :one
+SELECT id, type, created, source, processed_at, payload FROM webhook_events
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetWebhookEvent(ctx context.Context, db DBTX, id string) (WebhookEvent, error) {
+	row := db.QueryRowContext(ctx, GetWebhookEvent, id)
+	var i WebhookEvent
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.Created,
+		&i.Source,
+		&i.ProcessedAt,
+		&i.Payload,
+	)
+	return i, err
+}
+
+const GetWebhookSecretRotation = `-- name: GetWebhookSecretRotation :one
+SELECT id, status, old_endpoint_id, new_endpoint_id, new_secret, deliveries, confirmed_at, grace_ends_at, completed_at, cancelled_at, created_at, updated_at FROM webhook_secret_rotations
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetWebhookSecretRotation(ctx context.Context, db DBTX, id string) (WebhookSecretRotation, error) {
+	row := db.QueryRowContext(ctx, GetWebhookSecretRotation, id)
+	var i WebhookSecretRotation
+	err := row.Scan(
+		&i.ID,
+		&i.Status,
+		&i.OldEndpointID,
+		&i.NewEndpointID,
+		&i.NewSecret,
+		&i.Deliveries,
+		&i.ConfirmedAt,
+		&i.GraceEndsAt,
+		&i.CompletedAt,
+		&i.CancelledAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const InsertOutboxEvent = `-- name: InsertOutboxEvent :exec
+INSERT INTO event_outbox (
+    id, tenant_id, payload
+) VALUES (
+    $1, $2, $3
+)
+ON CONFLICT (id) DO NOTHING
+`
+
+type InsertOutboxEventParams struct {
+	ID       string          `json:"id"`
+	TenantID string          `json:"tenant_id"`
+	Payload  json.RawMessage `json:"payload"`
+}
+
+func (q *Queries) InsertOutboxEvent(ctx context.Context, db DBTX, arg InsertOutboxEventParams) error {
+	_, err := db.ExecContext(ctx, InsertOutboxEvent, arg.ID, arg.TenantID, arg.Payload)
+	return err
+}
+
+const InsertPaymentLinkConversion = `-- name: InsertPaymentLinkConversion :execrows
+INSERT INTO payment_link_conversions (
+    session_id, payment_link_id, amount_total, currency, customer_id, payment_intent_id, completed_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+ON CONFLICT (session_id) DO NOTHING
+`
+
+type InsertPaymentLinkConversionParams struct {
+	SessionID       string    `json:"session_id"`
+	PaymentLinkID   string    `json:"payment_link_id"`
+	AmountTotal     int64     `json:"amount_total"`
+	Currency        string    `json:"currency"`
+	CustomerID      string    `json:"customer_id"`
+	PaymentIntentID string    `json:"payment_intent_id"`
+	CompletedAt     time.Time `json:"completed_at"`
+}
+
+func (q *Queries) InsertPaymentLinkConversion(ctx context.Context, db DBTX, arg InsertPaymentLinkConversionParams) (int64, error) {
+	result, err := db.ExecContext(ctx, InsertPaymentLinkConversion,
+		arg.SessionID,
+		arg.PaymentLinkID,
+		arg.AmountTotal,
+		arg.Currency,
+		arg.CustomerID,
+		arg.PaymentIntentID,
+		arg.CompletedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const InsertUsageRecord = `-- name: InsertUsageRecord :execrows
+INSERT INTO usage_records (
+    id, subscription_id, subscription_item_id, price_id, quantity, action, recorded_at, idempotency_key
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+ON CONFLICT DO NOTHING
+`
+
+type InsertUsageRecordParams struct {
+	ID                 string    `json:"id"`
+	SubscriptionID     string    `json:"subscription_id"`
+	SubscriptionItemID string    `json:"subscription_item_id"`
+	PriceID            string    `json:"price_id"`
+	Quantity           int64     `json:"quantity"`
+	Action             string    `json:"action"`
+	RecordedAt         time.Time `json:"recorded_at"`
+	IdempotencyKey     string    `json:"idempotency_key"`
+}
+
+func (q *Queries) InsertUsageRecord(ctx context.Context, db DBTX, arg InsertUsageRecordParams) (int64, error) {
+	result, err := db.ExecContext(ctx, InsertUsageRecord,
+		arg.ID,
+		arg.SubscriptionID,
+		arg.SubscriptionItemID,
+		arg.PriceID,
+		arg.Quantity,
+		arg.Action,
+		arg.RecordedAt,
+		arg.IdempotencyKey,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const LiftQuarantine = `-- name: LiftQuarantine :one
+UPDATE quarantines
+SET lifted_by = $2, lifted_at = NOW()
+WHERE id = $1 AND lifted_at IS NULL
+RETURNING id, subject_type, subject_id, reason, created_by, lifted_by, lifted_at, created_at, updated_at
+`
+
+type LiftQuarantineParams struct {
+	ID       string         `json:"id"`
+	LiftedBy sql.NullString `json:"lifted_by"`
+}
+
+func (q *Queries) LiftQuarantine(ctx context.Context, db DBTX, arg LiftQuarantineParams) (Quarantine, error) {
+	row := db.QueryRowContext(ctx, LiftQuarantine, arg.ID, arg.LiftedBy)
+	var i Quarantine
+	err := row.Scan(
+		&i.ID,
+		&i.SubjectType,
+		&i.SubjectID,
+		&i.Reason,
+		&i.CreatedBy,
+		&i.LiftedBy,
+		&i.LiftedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const ListAPIKeys = `-- name: ListAPIKeys :many
+SELECT id, name, secret_hash, secret_hint, scopes, tenant_id, created_by, rotated_from, expires_at, revoked_at, created_at FROM api_keys
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListAPIKeys(ctx context.Context, db DBTX) ([]ApiKey, error) {
+	rows, err := db.QueryContext(ctx, ListAPIKeys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ApiKey{}
+	for rows.Next() {
+		var i ApiKey
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.SecretHash,
+			&i.SecretHint,
+			&i.Scopes,
+			&i.TenantID,
+			&i.CreatedBy,
+			&i.RotatedFrom,
+			&i.ExpiresAt,
+			&i.RevokedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListActiveCustomerHolds = `-- name: ListActiveCustomerHolds :many
+SELECT id, tenant_id, customer_id, reason, source_id, status, blocks_charges, paused_subscriptions, release_reason, created_at, updated_at, released_at FROM customer_holds
+WHERE customer_id = $1 AND status = 'active'
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListActiveCustomerHolds(ctx context.Context, db DBTX, customerID string) ([]CustomerHold, error) {
+	rows, err := db.QueryContext(ctx, ListActiveCustomerHolds, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CustomerHold{}
+	for rows.Next() {
+		var i CustomerHold
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.CustomerID,
+			&i.Reason,
+			&i.SourceID,
+			&i.Status,
+			&i.BlocksCharges,
+			&i.PausedSubscriptions,
+			&i.ReleaseReason,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ReleasedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListActiveQuarantines = `-- name: ListActiveQuarantines :many
+SELECT id, subject_type, subject_id, reason, created_by, lifted_by, lifted_at, created_at, updated_at FROM quarantines
+WHERE lifted_at IS NULL
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListActiveQuarantines(ctx context.Context, db DBTX) ([]Quarantine, error) {
+	rows, err := db.QueryContext(ctx, ListActiveQuarantines)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Quarantine{}
+	for rows.Next() {
+		var i Quarantine
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubjectType,
+			&i.SubjectID,
+			&i.Reason,
+			&i.CreatedBy,
+			&i.LiftedBy,
+			&i.LiftedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListAllCharges = `-- name: ListAllCharges :many
+SELECT id, amount, currency, status, customer_id, payment_method_id, description, metadata, created_at, updated_at, amount_refunded, captured, refunded, disputed, invoice_id, synced_at, tenant_id FROM charges
+ORDER BY created_at DESC
+LIMIT $1 OFFSET $2
+`
+
+type ListAllChargesParams struct {
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+func (q *Queries) ListAllCharges(ctx context.Context, db DBTX, arg ListAllChargesParams) ([]Charge, error) {
+	rows, err := db.QueryContext(ctx, ListAllCharges, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Charge{}
+	for rows.Next() {
+		var i Charge
+		if err := rows.Scan(
+			&i.ID,
+			&i.Amount,
+			&i.Currency,
+			&i.Status,
+			&i.CustomerID,
+			&i.PaymentMethodID,
+			&i.Description,
+			&i.Metadata,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.AmountRefunded,
+			&i.Captured,
+			&i.Refunded,
+			&i.Disputed,
+			&i.InvoiceID,
+			&i.SyncedAt,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListAllRefunds = `-- name: ListAllRefunds :many
+SELECT id, charge_id, amount, currency, status, reason, metadata, created_at, updated_at, synced_at, tenant_id FROM refunds
+ORDER BY created_at DESC
+LIMIT $1 OFFSET $2
+`
+
+type ListAllRefundsParams struct {
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+func (q *Queries) ListAllRefunds(ctx context.Context, db DBTX, arg ListAllRefundsParams) ([]Refund, error) {
+	rows, err := db.QueryContext(ctx, ListAllRefunds, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Refund{}
+	for rows.Next() {
+		var i Refund
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChargeID,
+			&i.Amount,
+			&i.Currency,
+			&i.Status,
+			&i.Reason,
+			&i.Metadata,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.SyncedAt,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListArchivedEvents = `-- name: ListArchivedEvents :many
+SELECT id, tenant_id, type, subject, occurred_at, payload, archived_at FROM event_archive
+WHERE occurred_at >= $1
+  AND occurred_at < $2
+  AND ($3::text = '' OR tenant_id = $3::text)
+  AND ($4::text = '' OR type LIKE $4::text)
+  AND ($5::text = '' OR subject = $5::text)
+  AND (occurred_at, id) > ($6::timestamptz, $7::text)
+ORDER BY occurred_at, id
+LIMIT $8
+`
+
+type ListArchivedEventsParams struct {
+	OccurredFrom time.Time `json:"occurred_from"`
+	OccurredTo   time.Time `json:"occurred_to"`
+	TenantID     string    `json:"tenant_id"`
+	TypePattern  string    `json:"type_pattern"`
+	Subject      string    `json:"subject"`
+	AfterTime    time.Time `json:"after_time"`
+	AfterID      string    `json:"after_id"`
+	Limit        int32     `json:"limit"`
+}
+
+func (q *Queries) ListArchivedEvents(ctx context.Context, db DBTX, arg ListArchivedEventsParams) ([]EventArchive, error) {
+	rows, err := db.QueryContext(ctx, ListArchivedEvents,
+		arg.OccurredFrom,
+		arg.OccurredTo,
+		arg.TenantID,
+		arg.TypePattern,
+		arg.Subject,
+		arg.AfterTime,
+		arg.AfterID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []EventArchive{}
+	for rows.Next() {
+		var i EventArchive
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.Type,
+			&i.Subject,
+			&i.OccurredAt,
+			&i.Payload,
+			&i.ArchivedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListAuditEntries = `-- name: ListAuditEntries :many
+SELECT sequence, tenant_id, actor_type, actor_id, operator_id, action, resource_type, resource_id, method, path, status, before_state, after_state, changes, ip, request_id, created_at, previous_hash, hash FROM audit_log
+WHERE ($1::text = '' OR tenant_id = $1::text)
+  AND ($2::text = '' OR actor_id = $2::text)
+  AND ($3::text = '' OR action = $3::text)
+  AND ($4::text = '' OR resource_type = $4::text)
+  AND ($5::text = '' OR resource_id = $5::text)
+  AND ($6::timestamptz IS NULL OR created_at >= $6)
+  AND ($7::timestamptz IS NULL OR created_at < $7)
+  AND ($8::bigint = 0 OR sequence < $8::bigint)
+ORDER BY sequence DESC
+LIMIT $9
+`
+
+type ListAuditEntriesParams struct {
+	TenantID       string       `json:"tenant_id"`
+	ActorID        string       `json:"actor_id"`
+	Action         string       `json:"action"`
+	ResourceType   string       `json:"resource_type"`
+	ResourceID     string       `json:"resource_id"`
+	CreatedAfter   sql.NullTime `json:"created_after"`
+	CreatedBefore  sql.NullTime `json:"created_before"`
+	BeforeSequence int64        `json:"before_sequence"`
+	Limit          int32        `json:"limit"`
+}
+
+func (q *Queries) ListAuditEntries(ctx context.Context, db DBTX, arg ListAuditEntriesParams) ([]AuditLog, error) {
+	rows, err := db.QueryContext(ctx, ListAuditEntries,
+		arg.TenantID,
+		arg.ActorID,
+		arg.Action,
+		arg.ResourceType,
+		arg.ResourceID,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.BeforeSequence,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AuditLog{}
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.Sequence,
+			&i.TenantID,
+			&i.ActorType,
+			&i.ActorID,
+			&i.OperatorID,
+			&i.Action,
+			&i.ResourceType,
+			&i.ResourceID,
+			&i.Method,
+			&i.Path,
+			&i.Status,
+			&i.BeforeState,
+			&i.AfterState,
+			&i.Changes,
+			&i.Ip,
+			&i.RequestID,
+			&i.CreatedAt,
+			&i.PreviousHash,
+			&i.Hash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListAuditEntriesFrom = `-- name: ListAuditEntriesFrom :many
+SELECT sequence, tenant_id, actor_type, actor_id, operator_id, action, resource_type, resource_id, method, path, status, before_state, after_state, changes, ip, request_id, created_at, previous_hash, hash FROM audit_log
+WHERE sequence >= $1
+ORDER BY sequence
+LIMIT $2
+`
+
+type ListAuditEntriesFromParams struct {
+	Sequence int64 `json:"sequence"`
+	Limit    int32 `json:"limit"`
+}
+
+func (q *Queries) ListAuditEntriesFrom(ctx context.Context, db DBTX, arg ListAuditEntriesFromParams) ([]AuditLog, error) {
+	rows, err := db.QueryContext(ctx, ListAuditEntriesFrom, arg.Sequence, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AuditLog{}
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.Sequence,
+			&i.TenantID,
+			&i.ActorType,
+			&i.ActorID,
+			&i.OperatorID,
+			&i.Action,
+			&i.ResourceType,
+			&i.ResourceID,
+			&i.Method,
+			&i.Path,
+			&i.Status,
+			&i.BeforeState,
+			&i.AfterState,
+			&i.Changes,
+			&i.Ip,
+			&i.RequestID,
+			&i.CreatedAt,
+			&i.PreviousHash,
+			&i.Hash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListAuthorizations = `-- name: ListAuthorizations :many
+SELECT charge_id, tenant_id, provider, amount, amount_captured, currency, captures, multi_capture, status, expires_at, closed_at, created_at, updated_at FROM authorizations
+WHERE ($1 = '' OR tenant_id = $1) AND ($2 = '' OR status = $2)
+ORDER BY created_at DESC
+LIMIT $3
+`
+
+type ListAuthorizationsParams struct {
+	TenantID string `json:"tenant_id"`
+	Status   string `json:"status"`
+	Limit    int32  `json:"limit"`
+}
+
+func (q *Queries) ListAuthorizations(ctx context.Context, db DBTX, arg ListAuthorizationsParams) ([]Authorization, error) {
+	rows, err := db.QueryContext(ctx, ListAuthorizations, arg.TenantID, arg.Status, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Authorization{}
+	for rows.Next() {
+		var i Authorization
+		if err := rows.Scan(
+			&i.ChargeID,
+			&i.TenantID,
+			&i.Provider,
+			&i.Amount,
+			&i.AmountCaptured,
+			&i.Currency,
+			&i.Captures,
+			&i.MultiCapture,
+			&i.Status,
+			&i.ExpiresAt,
+			&i.ClosedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListAutoRefundExclusions = `-- name: ListAutoRefundExclusions :many
+SELECT customer_id, reason, created_at FROM auto_refund_exclusions
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListAutoRefundExclusions(ctx context.Context, db DBTX) ([]AutoRefundExclusion, error) {
+	rows, err := db.QueryContext(ctx, ListAutoRefundExclusions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AutoRefundExclusion{}
+	for rows.Next() {
+		var i AutoRefundExclusion
+		if err := rows.Scan(&i.CustomerID, &i.Reason, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListAutoRefunds = `-- name: ListAutoRefunds :many
+SELECT id, customer_id, currency, amount, refund_id, status, failure_reason, funded_at, created_at FROM auto_refunds
+WHERE $1 = '' OR customer_id = $1
+ORDER BY created_at DESC
+LIMIT $2
+`
+
+type ListAutoRefundsParams struct {
+	CustomerID string `json:"customer_id"`
+	Limit      int32  `json:"limit"`
+}
+
+func (q *Queries) ListAutoRefunds(ctx context.Context, db DBTX, arg ListAutoRefundsParams) ([]AutoRefund, error) {
+	rows, err := db.QueryContext(ctx, ListAutoRefunds, arg.CustomerID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AutoRefund{}
+	for rows.Next() {
+		var i AutoRefund
+		if err := rows.Scan(
+			&i.ID,
+			&i.CustomerID,
+			&i.Currency,
+			&i.Amount,
+			&i.RefundID,
+			&i.Status,
+			&i.FailureReason,
+			&i.FundedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListBlocklistEntries = `-- name: ListBlocklistEntries :many
+SELECT id, entry_type, value, reason, source, created_by, provider_item_id, created_at, updated_at FROM blocklist_entries
+WHERE $1 = '' OR entry_type = $1
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListBlocklistEntries(ctx context.Context, db DBTX, entryType string) ([]BlocklistEntry, error) {
+	rows, err := db.QueryContext(ctx, ListBlocklistEntries, entryType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []BlocklistEntry{}
+	for rows.Next() {
+		var i BlocklistEntry
+		if err := rows.Scan(
+			&i.ID,
+			&i.EntryType,
+			&i.Value,
+			&i.Reason,
+			&i.Source,
+			&i.CreatedBy,
+			&i.ProviderItemID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListCapturedChargesCreatedBetween = `-- name: ListCapturedChargesCreatedBetween :many
+SELECT id, amount, currency, status, customer_id, payment_method_id, description, metadata, created_at, updated_at, amount_refunded, captured, refunded, disputed, invoice_id, synced_at, tenant_id FROM charges
+WHERE captured = TRUE AND created_at >= $1 AND created_at < $2
+ORDER BY created_at
+`
+
+type ListCapturedChargesCreatedBetweenParams struct {
+	CreatedFrom sql.NullTime `json:"created_from"`
+	CreatedTo   sql.NullTime `json:"created_to"`
+}
+
+func (q *Queries) ListCapturedChargesCreatedBetween(ctx context.Context, db DBTX, arg ListCapturedChargesCreatedBetweenParams) ([]Charge, error) {
+	rows, err := db.QueryContext(ctx, ListCapturedChargesCreatedBetween, arg.CreatedFrom, arg.CreatedTo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Charge{}
+	for rows.Next() {
+		var i Charge
+		if err := rows.Scan(
+			&i.ID,
+			&i.Amount,
+			&i.Currency,
+			&i.Status,
+			&i.CustomerID,
+			&i.PaymentMethodID,
+			&i.Description,
+			&i.Metadata,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.AmountRefunded,
+			&i.Captured,
+			&i.Refunded,
+			&i.Disputed,
+			&i.InvoiceID,
+			&i.SyncedAt,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListChargeListRows = `-- name: ListChargeListRows :many
+SELECT charge_id, tenant_id, customer_id, customer_email, customer_name, plan_name, amount, amount_refunded, currency, status, description, last_refund_id, last_refund_status, charge_created, created_at, updated_at FROM charge_list_rows
+WHERE tenant_id = $1
+    AND ($2 = '' OR customer_id = $2)
+    AND ($3 = '' OR status = $3)
+ORDER BY charge_created DESC
+LIMIT $4 OFFSET $5
+`
+
+type ListChargeListRowsParams struct {
+	TenantID   string `json:"tenant_id"`
+	CustomerID string `json:"customer_id"`
+	Status     string `json:"status"`
+	Limit      int32  `json:"limit"`
+	Offset     int32  `json:"offset"`
+}
+
+func (q *Queries) ListChargeListRows(ctx context.Context, db DBTX, arg ListChargeListRowsParams) ([]ChargeListRow, error) {
+	rows, err := db.QueryContext(ctx, ListChargeListRows,
+		arg.TenantID,
+		arg.CustomerID,
+		arg.Status,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ChargeListRow{}
+	for rows.Next() {
+		var i ChargeListRow
+		if err := rows.Scan(
+			&i.ChargeID,
+			&i.TenantID,
+			&i.CustomerID,
+			&i.CustomerEmail,
+			&i.CustomerName,
+			&i.PlanName,
+			&i.Amount,
+			&i.AmountRefunded,
+			&i.Currency,
+			&i.Status,
+			&i.Description,
+			&i.LastRefundID,
+			&i.LastRefundStatus,
+			&i.ChargeCreated,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListChargeTransitions = `-- name: ListChargeTransitions :many
+SELECT id, charge_id, sequence, from_state, to_state, source, event_id, created_at FROM charge_transitions
+WHERE charge_id = $1
+ORDER BY sequence
+`
+
+func (q *Queries) ListChargeTransitions(ctx context.Context, db DBTX, chargeID string) ([]ChargeTransition, error) {
+	rows, err := db.QueryContext(ctx, ListChargeTransitions, chargeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ChargeTransition{}
+	for rows.Next() {
+		var i ChargeTransition
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChargeID,
+			&i.Sequence,
+			&i.FromState,
+			&i.ToState,
+			&i.Source,
+			&i.EventID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListCharges = `-- name: ListCharges :many
+SELECT id, amount, currency, status, customer_id, payment_method_id, description, metadata, created_at, updated_at, amount_refunded, captured, refunded, disputed, invoice_id, synced_at, tenant_id FROM charges
+WHERE customer_id = $1 AND ($4 = '' OR tenant_id = $4)
+ORDER BY created_at DESC
+LIMIT $2 OFFSET $3
+`
+
+type ListChargesParams struct {
+	CustomerID string `json:"customer_id"`
+	Limit      int32  `json:"limit"`
+	Offset     int32  `json:"offset"`
+	TenantID   string `json:"tenant_id"`
+}
+
+func (q *Queries) ListCharges(ctx context.Context, db DBTX, arg ListChargesParams) ([]Charge, error) {
+	rows, err := db.QueryContext(ctx, ListCharges,
+		arg.CustomerID,
+		arg.Limit,
+		arg.Offset,
+		arg.TenantID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Charge{}
+	for rows.Next() {
+		var i Charge
+		if err := rows.Scan(
+			&i.ID,
+			&i.Amount,
+			&i.Currency,
+			&i.Status,
+			&i.CustomerID,
+			&i.PaymentMethodID,
+			&i.Description,
+			&i.Metadata,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.AmountRefunded,
+			&i.Captured,
+			&i.Refunded,
+			&i.Disputed,
+			&i.InvoiceID,
+			&i.SyncedAt,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListCompositeChargeLegs = `-- name: ListCompositeChargeLegs :many
+SELECT composite_charge_id, charge_id, position, priority, amount FROM composite_charge_legs
+WHERE composite_charge_id = $1
+ORDER BY position
+`
+
+func (q *Queries) ListCompositeChargeLegs(ctx context.Context, db DBTX, compositeChargeID string) ([]CompositeChargeLeg, error) {
+	rows, err := db.QueryContext(ctx, ListCompositeChargeLegs, compositeChargeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CompositeChargeLeg{}
+	for rows.Next() {
+		var i CompositeChargeLeg
+		if err := rows.Scan(
+			&i.CompositeChargeID,
+			&i.ChargeID,
+			&i.Position,
+			&i.Priority,
+			&i.Amount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListCompositeRefundLegs = `-- name: ListCompositeRefundLegs :many
+SELECT composite_refund_id, charge_id, position, amount, refund_id, approval_id, status, failure_reason, created_at, updated_at FROM composite_refund_legs
+WHERE composite_refund_id = $1
+ORDER BY position
+`
+
+func (q *Queries) ListCompositeRefundLegs(ctx context.Context, db DBTX, compositeRefundID string) ([]CompositeRefundLeg, error) {
+	rows, err := db.QueryContext(ctx, ListCompositeRefundLegs, compositeRefundID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CompositeRefundLeg{}
+	for rows.Next() {
+		var i CompositeRefundLeg
+		if err := rows.Scan(
+			&i.CompositeRefundID,
+			&i.ChargeID,
+			&i.Position,
+			&i.Amount,
+			&i.RefundID,
+			&i.ApprovalID,
+			&i.Status,
+			&i.FailureReason,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListCompositeRefunds = `-- name: ListCompositeRefunds :many
+SELECT id, composite_charge_id, tenant_id, amount, currency, reason, status, created_at, updated_at FROM composite_refunds
+WHERE composite_charge_id = $1 AND ($2 = '' OR tenant_id = $2)
+ORDER BY created_at
+`
+
+type ListCompositeRefundsParams struct {
+	CompositeChargeID string `json:"composite_charge_id"`
+	TenantID          string `json:"tenant_id"`
+}
+
+func (q *Queries) ListCompositeRefunds(ctx context.Context, db DBTX, arg ListCompositeRefundsParams) ([]CompositeRefund, error) {
+	rows, err := db.QueryContext(ctx, ListCompositeRefunds, arg.CompositeChargeID, arg.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CompositeRefund{}
+	for rows.Next() {
+		var i CompositeRefund
+		if err := rows.Scan(
+			&i.ID,
+			&i.CompositeChargeID,
+			&i.TenantID,
+			&i.Amount,
+			&i.Currency,
+			&i.Reason,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListCustomerBalanceTransactions = `-- name: ListCustomerBalanceTransactions :many
+SELECT id, tenant_id, customer_id, type, reason, amount, currency, balance_after, description, source_id, provider_transaction_id, created_by, created_at FROM customer_balance_transactions
+WHERE tenant_id = $1 AND customer_id = $2
+ORDER BY created_at DESC, id DESC
+LIMIT $3
+`
+
+type ListCustomerBalanceTransactionsParams struct {
+	TenantID   string `json:"tenant_id"`
+	CustomerID string `json:"customer_id"`
+	Limit      int32  `json:"limit"`
+}
+
+func (q *Queries) ListCustomerBalanceTransactions(ctx context.Context, db DBTX, arg ListCustomerBalanceTransactionsParams) ([]CustomerBalanceTransaction, error) {
+	rows, err := db.QueryContext(ctx, ListCustomerBalanceTransactions, arg.TenantID, arg.CustomerID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CustomerBalanceTransaction{}
+	for rows.Next() {
+		var i CustomerBalanceTransaction
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.CustomerID,
+			&i.Type,
+			&i.Reason,
+			&i.Amount,
+			&i.Currency,
+			&i.BalanceAfter,
+			&i.Description,
+			&i.SourceID,
+			&i.ProviderTransactionID,
+			&i.CreatedBy,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListCustomerBalances = `-- name: ListCustomerBalances :many
+SELECT tenant_id, customer_id, currency, amount, created_at, updated_at FROM customer_balances
+WHERE tenant_id = $1 AND customer_id = $2
+ORDER BY currency
+`
+
+type ListCustomerBalancesParams struct {
+	TenantID   string `json:"tenant_id"`
+	CustomerID string `json:"customer_id"`
+}
+
+func (q *Queries) ListCustomerBalances(ctx context.Context, db DBTX, arg ListCustomerBalancesParams) ([]CustomerBalance, error) {
+	rows, err := db.QueryContext(ctx, ListCustomerBalances, arg.TenantID, arg.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CustomerBalance{}
+	for rows.Next() {
+		var i CustomerBalance
+		if err := rows.Scan(
+			&i.TenantID,
+			&i.CustomerID,
+			&i.Currency,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListCustomerHolds = `-- name: ListCustomerHolds :many
+SELECT id, tenant_id, customer_id, reason, source_id, status, blocks_charges, paused_subscriptions, release_reason, created_at, updated_at, released_at FROM customer_holds
+WHERE customer_id = $1 AND ($2 = '' OR tenant_id = $2)
+ORDER BY created_at DESC
+`
+
+type ListCustomerHoldsParams struct {
+	CustomerID string `json:"customer_id"`
+	TenantID   string `json:"tenant_id"`
+}
+
+func (q *Queries) ListCustomerHolds(ctx context.Context, db DBTX, arg ListCustomerHoldsParams) ([]CustomerHold, error) {
+	rows, err := db.QueryContext(ctx, ListCustomerHolds, arg.CustomerID, arg.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CustomerHold{}
+	for rows.Next() {
+		var i CustomerHold
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.CustomerID,
+			&i.Reason,
+			&i.SourceID,
+			&i.Status,
+			&i.BlocksCharges,
+			&i.PausedSubscriptions,
+			&i.ReleaseReason,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ReleasedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListCustomerIdentitiesByEmail = `-- name: ListCustomerIdentitiesByEmail :many
+SELECT customer_id, tenant_id, normalized_email, name, email_verified_at, verification_token_hash, verification_expires_at, created_at, updated_at FROM customer_identities
+WHERE tenant_id = $1 AND normalized_email = $2
+ORDER BY created_at ASC
+`
+
+type ListCustomerIdentitiesByEmailParams struct {
+	TenantID        string `json:"tenant_id"`
+	NormalizedEmail string `json:"normalized_email"`
+}
+
+func (q *Queries) ListCustomerIdentitiesByEmail(ctx context.Context, db DBTX, arg ListCustomerIdentitiesByEmailParams) ([]CustomerIdentity, error) {
+	rows, err := db.QueryContext(ctx, ListCustomerIdentitiesByEmail, arg.TenantID, arg.NormalizedEmail)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CustomerIdentity{}
+	for rows.Next() {
+		var i CustomerIdentity
+		if err := rows.Scan(
+			&i.CustomerID,
+			&i.TenantID,
+			&i.NormalizedEmail,
+			&i.Name,
+			&i.EmailVerifiedAt,
+			&i.VerificationTokenHash,
+			&i.VerificationExpiresAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListCustomers = `-- name: ListCustomers :many
+SELECT id, email, name, phone, description, metadata, created_at, updated_at, synced_at, tenant_id FROM customers
+ORDER BY created_at DESC
+LIMIT $1 OFFSET $2
+`
+
+type ListCustomersParams struct {
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+func (q *Queries) ListCustomers(ctx context.Context, db DBTX, arg ListCustomersParams) ([]Customer, error) {
+	rows, err := db.QueryContext(ctx, ListCustomers, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Customer{}
+	for rows.Next() {
+		var i Customer
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+			&i.Name,
+			&i.Phone,
+			&i.Description,
+			&i.Metadata,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.SyncedAt,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListDeadLetters = `-- name: ListDeadLetters :many
+SELECT id, source, dedupe_key, payload, headers, status, attempts, last_error, next_attempt_at, resolved_at, created_at, updated_at FROM dlq_events
+WHERE ($1 = '' OR status = $1) AND ($2 = '' OR source = $2)
+ORDER BY created_at DESC
+LIMIT $3
+`
+
+type ListDeadLettersParams struct {
+	Status string `json:"status"`
+	Source string `json:"source"`
+	Limit  int32  `json:"limit"`
+}
+
+func (q *Queries) ListDeadLetters(ctx context.Context, db DBTX, arg ListDeadLettersParams) ([]DlqEvent, error) {
+	rows, err := db.QueryContext(ctx, ListDeadLetters, arg.Status, arg.Source, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []DlqEvent{}
+	for rows.Next() {
+		var i DlqEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.Source,
+			&i.DedupeKey,
+			&i.Payload,
+			&i.Headers,
+			&i.Status,
+			&i.Attempts,
+			&i.LastError,
+			&i.NextAttemptAt,
+			&i.ResolvedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListDeprecatedUsage = `-- name: ListDeprecatedUsage :many
+SELECT notice_id, api_key_id, request_count, first_seen, last_seen FROM deprecated_usage
+ORDER BY notice_id, last_seen DESC
+`
+
+func (q *Queries) ListDeprecatedUsage(ctx context.Context, db DBTX) ([]DeprecatedUsage, error) {
+	rows, err := db.QueryContext(ctx, ListDeprecatedUsage)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []DeprecatedUsage{}
+	for rows.Next() {
+		var i DeprecatedUsage
+		if err := rows.Scan(
+			&i.NoticeID,
+			&i.ApiKeyID,
+			&i.RequestCount,
+			&i.FirstSeen,
+			&i.LastSeen,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListDisputeEvidenceFiles = `-- name: ListDisputeEvidenceFiles :many
+SELECT id, dispute_id, provider_file_id, field, filename, content_type, size, uploaded_by, created_at FROM dispute_evidence_files
+WHERE dispute_id = $1
+ORDER BY created_at
+`
+
+func (q *Queries) ListDisputeEvidenceFiles(ctx context.Context, db DBTX, disputeID string) ([]DisputeEvidenceFile, error) {
+	rows, err := db.QueryContext(ctx, ListDisputeEvidenceFiles, disputeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []DisputeEvidenceFile{}
+	for rows.Next() {
+		var i DisputeEvidenceFile
+		if err := rows.Scan(
+			&i.ID,
+			&i.DisputeID,
+			&i.ProviderFileID,
+			&i.Field,
+			&i.Filename,
+			&i.ContentType,
+			&i.Size,
+			&i.UploadedBy,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListDisputes = `-- name: ListDisputes :many
+SELECT id, charge_id, payment_intent_id, amount, currency, reason, status, network_reason_code, is_charge_refundable, evidence, evidence_due_by, has_evidence, past_due, submission_count, payment_method_type, card_brand, card_network_reason_code, balance_transactions, livemode, metadata, disputed_at, synced_at, created_at, updated_at, tenant_id FROM disputes
+WHERE ($1 = '' OR charge_id = $1) AND ($2 = '' OR status = $2) AND ($4 = '' OR tenant_id = $4)
+ORDER BY disputed_at DESC
+LIMIT $3
+`
+
+type ListDisputesParams struct {
+	ChargeID string `json:"charge_id"`
+	Status   string `json:"status"`
+	Limit    int32  `json:"limit"`
+	TenantID string `json:"tenant_id"`
+}
+
+func (q *Queries) ListDisputes(ctx context.Context, db DBTX, arg ListDisputesParams) ([]Dispute, error) {
+	rows, err := db.QueryContext(ctx, ListDisputes, arg.ChargeID, arg.Status, arg.Limit, arg.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Dispute{}
+	for rows.Next() {
+		var i Dispute
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChargeID,
+			&i.PaymentIntentID,
+			&i.Amount,
+			&i.Currency,
+			&i.Reason,
+			&i.Status,
+			&i.NetworkReasonCode,
+			&i.IsChargeRefundable,
+			&i.Evidence,
+			&i.EvidenceDueBy,
+			&i.HasEvidence,
+			&i.PastDue,
+			&i.SubmissionCount,
+			&i.PaymentMethodType,
+			&i.CardBrand,
+			&i.CardNetworkReasonCode,
+			&i.BalanceTransactions,
+			&i.Livemode,
+			&i.Metadata,
+			&i.DisputedAt,
+			&i.SyncedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListDisputesDueForEvidence = `-- name: ListDisputesDueForEvidence :many
+SELECT id, charge_id, payment_intent_id, amount, currency, reason, status, network_reason_code, is_charge_refundable, evidence, evidence_due_by, has_evidence, past_due, submission_count, payment_method_type, card_brand, card_network_reason_code, balance_transactions, livemode, metadata, disputed_at, synced_at, created_at, updated_at, tenant_id FROM disputes
+WHERE status IN ('needs_response', 'warning_needs_response')
+  AND submission_count = 0
+  AND evidence_due_by > $1
+  AND evidence_due_by <= $2
+ORDER BY evidence_due_by
+`
+
+type ListDisputesDueForEvidenceParams struct {
+	DueAfter  sql.NullTime `json:"due_after"`
+	DueBefore sql.NullTime `json:"due_before"`
+}
+
+func (q *Queries) ListDisputesDueForEvidence(ctx context.Context, db DBTX, arg ListDisputesDueForEvidenceParams) ([]Dispute, error) {
+	rows, err := db.QueryContext(ctx, ListDisputesDueForEvidence, arg.DueAfter, arg.DueBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Dispute{}
+	for rows.Next() {
+		var i Dispute
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChargeID,
+			&i.PaymentIntentID,
+			&i.Amount,
+			&i.Currency,
+			&i.Reason,
+			&i.Status,
+			&i.NetworkReasonCode,
+			&i.IsChargeRefundable,
+			&i.Evidence,
+			&i.EvidenceDueBy,
+			&i.HasEvidence,
+			&i.PastDue,
+			&i.SubmissionCount,
+			&i.PaymentMethodType,
+			&i.CardBrand,
+			&i.CardNetworkReasonCode,
+			&i.BalanceTransactions,
+			&i.Livemode,
+			&i.Metadata,
+			&i.DisputedAt,
+			&i.SyncedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListDueCustomerDeletions = `-- name: ListDueCustomerDeletions :many
+SELECT customer_id, tenant_id, deleted_at, purge_after, purged_at, created_at FROM customer_deletions
+WHERE purge_after <= $1 AND purged_at IS NULL
+ORDER BY purge_after
+LIMIT $2
+`
+
+type ListDueCustomerDeletionsParams struct {
+	PurgeAfter time.Time `json:"purge_after"`
+	Limit      int32     `json:"limit"`
+}
+
+func (q *Queries) ListDueCustomerDeletions(ctx context.Context, db DBTX, arg ListDueCustomerDeletionsParams) ([]CustomerDeletion, error) {
+	rows, err := db.QueryContext(ctx, ListDueCustomerDeletions, arg.PurgeAfter, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CustomerDeletion{}
+	for rows.Next() {
+		var i CustomerDeletion
+		if err := rows.Scan(
+			&i.CustomerID,
+			&i.TenantID,
+			&i.DeletedAt,
+			&i.PurgeAfter,
+			&i.PurgedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListDueUnclaimedBalances = `-- name: ListDueUnclaimedBalances :many
+SELECT customer_id, currency, amount, funded_at, created_at, updated_at FROM unclaimed_balances
+WHERE amount > 0 AND funded_at <= $1
+ORDER BY funded_at
+`
+
+func (q *Queries) ListDueUnclaimedBalances(ctx context.Context, db DBTX, fundedAt sql.NullTime) ([]UnclaimedBalance, error) {
+	rows, err := db.QueryContext(ctx, ListDueUnclaimedBalances, fundedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []UnclaimedBalance{}
+	for rows.Next() {
+		var i UnclaimedBalance
+		if err := rows.Scan(
+			&i.CustomerID,
+			&i.Currency,
+			&i.Amount,
+			&i.FundedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListDunningCases = `-- name: ListDunningCases :many
+SELECT id, invoice_id, subscription_id, customer_id, amount_due, currency, state, attempts, next_retry_at, last_error, failed_at, resolved_at, created_at, updated_at, tenant_id FROM dunning_cases
+WHERE ($1 = '' OR state = $1) AND ($2 = '' OR customer_id = $2) AND ($3 = '' OR subscription_id = $3) AND ($5 = '' OR tenant_id = $5)
+ORDER BY created_at DESC
+LIMIT $4
+`
+
+type ListDunningCasesParams struct {
+	State          string `json:"state"`
+	CustomerID     string `json:"customer_id"`
+	SubscriptionID string `json:"subscription_id"`
+	Limit          int32  `json:"limit"`
+	TenantID       string `json:"tenant_id"`
+}
+
+func (q *Queries) ListDunningCases(ctx context.Context, db DBTX, arg ListDunningCasesParams) ([]DunningCase, error) {
+	rows, err := db.QueryContext(ctx, ListDunningCases,
+		arg.State,
+		arg.CustomerID,
+		arg.SubscriptionID,
+		arg.Limit,
+		arg.TenantID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []DunningCase{}
+	for rows.Next() {
+		var i DunningCase
+		if err := rows.Scan(
+			&i.ID,
+			&i.InvoiceID,
+			&i.SubscriptionID,
+			&i.CustomerID,
+			&i.AmountDue,
+			&i.Currency,
+			&i.State,
+			&i.Attempts,
+			&i.NextRetryAt,
+			&i.LastError,
+			&i.FailedAt,
+			&i.ResolvedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListEntityVersions = `-- name: ListEntityVersions :many
+SELECT id, entity_type, entity_id, status, state, event_id, event_type, valid_from, recorded_at FROM entity_versions
+WHERE entity_type = $1 AND entity_id = $2
+ORDER BY valid_from, id
+`
+
+type ListEntityVersionsParams struct {
+	EntityType string `json:"entity_type"`
+	EntityID   string `json:"entity_id"`
+}
+
+func (q *Queries) ListEntityVersions(ctx context.Context, db DBTX, arg ListEntityVersionsParams) ([]EntityVersion, error) {
+	rows, err := db.QueryContext(ctx, ListEntityVersions, arg.EntityType, arg.EntityID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []EntityVersion{}
+	for rows.Next() {
+		var i EntityVersion
+		if err := rows.Scan(
+			&i.ID,
+			&i.EntityType,
+			&i.EntityID,
+			&i.Status,
+			&i.State,
+			&i.EventID,
+			&i.EventType,
+			&i.ValidFrom,
+			&i.RecordedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListExpiredPaymentLinks = `-- name: ListExpiredPaymentLinks :many
+SELECT id, url, amount, currency, product_name, price_id, active, expires_at, max_uses, conversions, amount_collected, metadata, deactivated_at, created_at, updated_at, tenant_id FROM payment_links
+WHERE active AND expires_at IS NOT NULL AND expires_at <= $1
+ORDER BY expires_at
+`
+
+func (q *Queries) ListExpiredPaymentLinks(ctx context.Context, db DBTX, expiresAt sql.NullTime) ([]PaymentLink, error) {
+	rows, err := db.QueryContext(ctx, ListExpiredPaymentLinks, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []PaymentLink{}
+	for rows.Next() {
+		var i PaymentLink
+		if err := rows.Scan(
+			&i.ID,
+			&i.Url,
+			&i.Amount,
+			&i.Currency,
+			&i.ProductName,
+			&i.PriceID,
+			&i.Active,
+			&i.ExpiresAt,
+			&i.MaxUses,
+			&i.Conversions,
+			&i.AmountCollected,
+			&i.Metadata,
+			&i.DeactivatedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListFraudListEntries = `-- name: ListFraudListEntries :many
+SELECT id, list, entry_type, value, reason, created_by, created_at FROM fraud_list_entries
+WHERE ($1 = '' OR list = $1)
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListFraudListEntries(ctx context.Context, db DBTX, list string) ([]FraudListEntry, error) {
+	rows, err := db.QueryContext(ctx, ListFraudListEntries, list)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []FraudListEntry{}
+	for rows.Next() {
+		var i FraudListEntry
+		if err := rows.Scan(
+			&i.ID,
+			&i.List,
+			&i.EntryType,
+			&i.Value,
+			&i.Reason,
+			&i.CreatedBy,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListFraudReviews = `-- name: ListFraudReviews :many
+SELECT id, tenant_id, screening_id, customer_id, amount, currency, request, findings, status, decided_by, charge_id, failure_reason, decided_at, created_at, updated_at FROM fraud_reviews
+WHERE ($1 = '' OR tenant_id = $1)
+  AND ($2 = '' OR status = $2)
+ORDER BY created_at DESC
+LIMIT $3
+`
+
+type ListFraudReviewsParams struct {
+	TenantID string `json:"tenant_id"`
+	Status   string `json:"status"`
+	Limit    int32  `json:"limit"`
+}
+
+func (q *Queries) ListFraudReviews(ctx context.Context, db DBTX, arg ListFraudReviewsParams) ([]FraudReview, error) {
+	rows, err := db.QueryContext(ctx, ListFraudReviews, arg.TenantID, arg.Status, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []FraudReview{}
+	for rows.Next() {
+		var i FraudReview
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.ScreeningID,
+			&i.CustomerID,
+			&i.Amount,
+			&i.Currency,
+			&i.Request,
+			&i.Findings,
+			&i.Status,
+			&i.DecidedBy,
+			&i.ChargeID,
+			&i.FailureReason,
+			&i.DecidedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListHeldMutations = `-- name: ListHeldMutations :many
+SELECT id, quarantine_id, tenant_id, api_key_id, operator_id, method, path, content_type, body, status, decided_by, decided_at, response_status, response_body, created_at, updated_at FROM held_mutations
+WHERE $1 = '' OR status = $1
+ORDER BY created_at ASC
+`
+
+func (q *Queries) ListHeldMutations(ctx context.Context, db DBTX, status string) ([]HeldMutation, error) {
+	rows, err := db.QueryContext(ctx, ListHeldMutations, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []HeldMutation{}
+	for rows.Next() {
+		var i HeldMutation
+		if err := rows.Scan(
+			&i.ID,
+			&i.QuarantineID,
+			&i.TenantID,
+			&i.ApiKeyID,
+			&i.OperatorID,
+			&i.Method,
+			&i.Path,
+			&i.ContentType,
+			&i.Body,
+			&i.Status,
+			&i.DecidedBy,
+			&i.DecidedAt,
+			&i.ResponseStatus,
+			&i.ResponseBody,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListInvoiceReminderOffsets = `-- name: ListInvoiceReminderOffsets :many
+SELECT offset_days FROM invoice_reminders
+WHERE invoice_id = $1
+ORDER BY offset_days
+`
+
+func (q *Queries) ListInvoiceReminderOffsets(ctx context.Context, db DBTX, invoiceID string) ([]int32, error) {
+	rows, err := db.QueryContext(ctx, ListInvoiceReminderOffsets, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int32{}
+	for rows.Next() {
+		var offset_days int32
+		if err := rows.Scan(&offset_days); err != nil {
+			return nil, err
+		}
+		items = append(items, offset_days)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListInvoices = `-- name: ListInvoices :many
+SELECT id, customer_id, subscription_id, number, status, collection_method, description, amount_due, amount_paid, amount_remaining, currency, due_date, paid_out_of_band, hosted_invoice_url, invoice_pdf, custom_fields, metadata, invoiced_at, synced_at, created_at, updated_at, customer_name, customer_email, subtotal, tax, total, lines, tax_amounts, tenant_id FROM invoices
+WHERE ($1 = '' OR customer_id = $1) AND ($2 = '' OR status = $2) AND ($4 = '' OR tenant_id = $4)
+ORDER BY invoiced_at DESC
+LIMIT $3
+`
+
+type ListInvoicesParams struct {
+	CustomerID string `json:"customer_id"`
+	Status     string `json:"status"`
+	Limit      int32  `json:"limit"`
+	TenantID   string `json:"tenant_id"`
+}
+
+func (q *Queries) ListInvoices(ctx context.Context, db DBTX, arg ListInvoicesParams) ([]Invoice, error) {
+	rows, err := db.QueryContext(ctx, ListInvoices, arg.CustomerID, arg.Status, arg.Limit, arg.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Invoice{}
+	for rows.Next() {
+		var i Invoice
+		if err := rows.Scan(
+			&i.ID,
+			&i.CustomerID,
+			&i.SubscriptionID,
+			&i.Number,
+			&i.Status,
+			&i.CollectionMethod,
+			&i.Description,
+			&i.AmountDue,
+			&i.AmountPaid,
+			&i.AmountRemaining,
+			&i.Currency,
+			&i.DueDate,
+			&i.PaidOutOfBand,
+			&i.HostedInvoiceUrl,
+			&i.InvoicePdf,
+			&i.CustomFields,
+			&i.Metadata,
+			&i.InvoicedAt,
+			&i.SyncedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CustomerName,
+			&i.CustomerEmail,
+			&i.Subtotal,
+			&i.Tax,
+			&i.Total,
+			&i.Lines,
+			&i.TaxAmounts,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListJobRuns = `-- name: ListJobRuns :many
+SELECT id, job_name, status, triggered_by, attempts, error, instance, started_at, finished_at, created_at FROM job_runs
+WHERE job_name = $1
+ORDER BY started_at DESC
+LIMIT $2
+`
+
+type ListJobRunsParams struct {
+	JobName string `json:"job_name"`
+	Limit   int32  `json:"limit"`
+}
+
+func (q *Queries) ListJobRuns(ctx context.Context, db DBTX, arg ListJobRunsParams) ([]JobRun, error) {
+	rows, err := db.QueryContext(ctx, ListJobRuns, arg.JobName, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []JobRun{}
+	for rows.Next() {
+		var i JobRun
+		if err := rows.Scan(
+			&i.ID,
+			&i.JobName,
+			&i.Status,
+			&i.TriggeredBy,
+			&i.Attempts,
+			&i.Error,
+			&i.Instance,
+			&i.StartedAt,
+			&i.FinishedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListLedgerAccountTotals = `-- name: ListLedgerAccountTotals :many
+SELECT account::text AS account, currency, COALESCE(SUM(debits), 0)::bigint AS debits, COALESCE(SUM(credits), 0)::bigint AS credits
+FROM (
+    SELECT debit_account AS account, currency, amount AS debits, 0 AS credits FROM ledger_entries WHERE created_at <= $1
+    UNION ALL
+    SELECT credit_account AS account, currency, 0 AS debits, amount AS credits FROM ledger_entries WHERE created_at <= $1
+) AS postings
+GROUP BY account, currency
+ORDER BY currency, account
+`
+
+type ListLedgerAccountTotalsRow struct {
+	Account  string `json:"account"`
+	Currency string `json:"currency"`
+	Debits   int64  `json:"debits"`
+	Credits  int64  `json:"credits"`
+}
+
+func (q *Queries) ListLedgerAccountTotals(ctx context.Context, db DBTX, asOf sql.NullTime) ([]ListLedgerAccountTotalsRow, error) {
+	rows, err := db.QueryContext(ctx, ListLedgerAccountTotals, asOf)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListLedgerAccountTotalsRow{}
+	for rows.Next() {
+		var i ListLedgerAccountTotalsRow
+		if err := rows.Scan(
+			&i.Account,
+			&i.Currency,
+			&i.Debits,
+			&i.Credits,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListLedgerEntriesByAccount = `-- name: ListLedgerEntriesByAccount :many
+SELECT id, debit_account, credit_account, amount, currency, reference_type, reference_id, description, created_at FROM ledger_entries
+WHERE (debit_account = $1 OR credit_account = $1)
+  AND ($2::text = '' OR currency = $2)
+ORDER BY created_at DESC, id DESC
+LIMIT $3 OFFSET $4
+`
+
+type ListLedgerEntriesByAccountParams struct {
+	Account  string `json:"account"`
+	Currency string `json:"currency"`
+	Limit    int32  `json:"limit"`
+	Offset   int32  `json:"offset"`
+}
+
+func (q *Queries) ListLedgerEntriesByAccount(ctx context.Context, db DBTX, arg ListLedgerEntriesByAccountParams) ([]LedgerEntry, error) {
+	rows, err := db.QueryContext(ctx, ListLedgerEntriesByAccount,
+		arg.Account,
+		arg.Currency,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []LedgerEntry{}
+	for rows.Next() {
+		var i LedgerEntry
+		if err := rows.Scan(
+			&i.ID,
+			&i.DebitAccount,
+			&i.CreditAccount,
+			&i.Amount,
+			&i.Currency,
+			&i.ReferenceType,
+			&i.ReferenceID,
+			&i.Description,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListLedgerEntriesByReference = `-- name: ListLedgerEntriesByReference :many
+SELECT id, debit_account, credit_account, amount, currency, reference_type, reference_id, description, created_at FROM ledger_entries
+WHERE reference_type = $1 AND reference_id = $2
+ORDER BY created_at
+`
+
+type ListLedgerEntriesByReferenceParams struct {
+	ReferenceType string `json:"reference_type"`
+	ReferenceID   string `json:"reference_id"`
+}
+
+func (q *Queries) ListLedgerEntriesByReference(ctx context.Context, db DBTX, arg ListLedgerEntriesByReferenceParams) ([]LedgerEntry, error) {
+	rows, err := db.QueryContext(ctx, ListLedgerEntriesByReference, arg.ReferenceType, arg.ReferenceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []LedgerEntry{}
+	for rows.Next() {
+		var i LedgerEntry
+		if err := rows.Scan(
+			&i.ID,
+			&i.DebitAccount,
+			&i.CreditAccount,
+			&i.Amount,
+			&i.Currency,
+			&i.ReferenceType,
+			&i.ReferenceID,
+			&i.Description,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListMetadataSchemas = `-- name: ListMetadataSchemas :many
+SELECT tenant_id, resource, schema, created_at, updated_at FROM metadata_schemas
+WHERE tenant_id = $1
+ORDER BY resource
+`
+
+func (q *Queries) ListMetadataSchemas(ctx context.Context, db DBTX, tenantID string) ([]MetadataSchema, error) {
+	rows, err := db.QueryContext(ctx, ListMetadataSchemas, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []MetadataSchema{}
+	for rows.Next() {
+		var i MetadataSchema
+		if err := rows.Scan(
+			&i.TenantID,
+			&i.Resource,
+			&i.Schema,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListOffboardingExports = `-- name: ListOffboardingExports :many
+SELECT id, tenant_id, status, recipient_public_key, manifest, archive_sha256, archive_size, error, pan_migration_status, pan_migration_destination, pan_migration_reference, pan_migration_requested_at, started_at, completed_at, created_at, updated_at FROM offboarding_exports
+WHERE tenant_id = $1
+ORDER BY created_at DESC
+LIMIT $2
+`
+
+type ListOffboardingExportsParams struct {
+	TenantID string `json:"tenant_id"`
+	Limit    int32  `json:"limit"`
+}
+
+func (q *Queries) ListOffboardingExports(ctx context.Context, db DBTX, arg ListOffboardingExportsParams) ([]OffboardingExport, error) {
+	rows, err := db.QueryContext(ctx, ListOffboardingExports, arg.TenantID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []OffboardingExport{}
+	for rows.Next() {
+		var i OffboardingExport
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.Status,
+			&i.RecipientPublicKey,
+			&i.Manifest,
+			&i.ArchiveSha256,
+			&i.ArchiveSize,
+			&i.Error,
+			&i.PanMigrationStatus,
+			&i.PanMigrationDestination,
+			&i.PanMigrationReference,
+			&i.PanMigrationRequestedAt,
+			&i.StartedAt,
+			&i.CompletedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListOpenDunningCases = `-- name: ListOpenDunningCases :many
+SELECT id, invoice_id, subscription_id, customer_id, amount_due, currency, state, attempts, next_retry_at, last_error, failed_at, resolved_at, created_at, updated_at, tenant_id FROM dunning_cases
+WHERE resolved_at IS NULL
+ORDER BY failed_at
+LIMIT $1
+`
+
+func (q *Queries) ListOpenDunningCases(ctx context.Context, db DBTX, limit int32) ([]DunningCase, error) {
+	rows, err := db.QueryContext(ctx, ListOpenDunningCases, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []DunningCase{}
+	for rows.Next() {
+		var i DunningCase
+		if err := rows.Scan(
+			&i.ID,
+			&i.InvoiceID,
+			&i.SubscriptionID,
+			&i.CustomerID,
+			&i.AmountDue,
+			&i.Currency,
+			&i.State,
+			&i.Attempts,
+			&i.NextRetryAt,
+			&i.LastError,
+			&i.FailedAt,
+			&i.ResolvedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListOpenReceivableInvoices = `-- name: ListOpenReceivableInvoices :many
+SELECT invoice_id, customer_id, subscription_id, number, amount_due, amount_remaining, currency, due_date, status, overdue_at, paid_at, paid_reference, created_at, updated_at FROM receivable_invoices
+WHERE status = 'open'
+ORDER BY due_date
+`
+
+func (q *Queries) ListOpenReceivableInvoices(ctx context.Context, db DBTX) ([]ReceivableInvoice, error) {
+	rows, err := db.QueryContext(ctx, ListOpenReceivableInvoices)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ReceivableInvoice{}
+	for rows.Next() {
+		var i ReceivableInvoice
+		if err := rows.Scan(
+			&i.InvoiceID,
+			&i.CustomerID,
+			&i.SubscriptionID,
+			&i.Number,
+			&i.AmountDue,
+			&i.AmountRemaining,
+			&i.Currency,
+			&i.DueDate,
+			&i.Status,
+			&i.OverdueAt,
+			&i.PaidAt,
+			&i.PaidReference,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListOutboxEvents = `-- name: ListOutboxEvents :many
+SELECT id, tenant_id, payload, created_at FROM event_outbox
+ORDER BY created_at, id
+LIMIT $1
+`
+
+func (q *Queries) ListOutboxEvents(ctx context.Context, db DBTX, limit int32) ([]EventOutbox, error) {
+	rows, err := db.QueryContext(ctx, ListOutboxEvents, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []EventOutbox{}
+	for rows.Next() {
+		var i EventOutbox
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.Payload,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListOverdueReceivableInvoices = `-- name: ListOverdueReceivableInvoices :many
+SELECT invoice_id, customer_id, subscription_id, number, amount_due, amount_remaining, currency, due_date, status, overdue_at, paid_at, paid_reference, created_at, updated_at FROM receivable_invoices
+WHERE status = 'open' AND due_date < $1 AND ($2 = '' OR customer_id = $2)
+ORDER BY due_date
+`
+
+type ListOverdueReceivableInvoicesParams struct {
+	DueDate    time.Time `json:"due_date"`
+	CustomerID string    `json:"customer_id"`
+}
+
+func (q *Queries) ListOverdueReceivableInvoices(ctx context.Context, db DBTX, arg ListOverdueReceivableInvoicesParams) ([]ReceivableInvoice, error) {
+	rows, err := db.QueryContext(ctx, ListOverdueReceivableInvoices, arg.DueDate, arg.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ReceivableInvoice{}
+	for rows.Next() {
+		var i ReceivableInvoice
+		if err := rows.Scan(
+			&i.InvoiceID,
+			&i.CustomerID,
+			&i.SubscriptionID,
+			&i.Number,
+			&i.AmountDue,
+			&i.AmountRemaining,
+			&i.Currency,
+			&i.DueDate,
+			&i.Status,
+			&i.OverdueAt,
+			&i.PaidAt,
+			&i.PaidReference,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListPaymentLinkConversions = `-- name: ListPaymentLinkConversions :many
+SELECT session_id, payment_link_id, amount_total, currency, customer_id, payment_intent_id, completed_at, created_at FROM payment_link_conversions
+WHERE payment_link_id = $1
+ORDER BY completed_at DESC
+LIMIT $2
+`
+
+type ListPaymentLinkConversionsParams struct {
+	PaymentLinkID string `json:"payment_link_id"`
+	Limit         int32  `json:"limit"`
+}
+
+func (q *Queries) ListPaymentLinkConversions(ctx context.Context, db DBTX, arg ListPaymentLinkConversionsParams) ([]PaymentLinkConversion, error) {
+	rows, err := db.QueryContext(ctx, ListPaymentLinkConversions, arg.PaymentLinkID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []PaymentLinkConversion{}
+	for rows.Next() {
+		var i PaymentLinkConversion
+		if err := rows.Scan(
+			&i.SessionID,
+			&i.PaymentLinkID,
+			&i.AmountTotal,
+			&i.Currency,
+			&i.CustomerID,
+			&i.PaymentIntentID,
+			&i.CompletedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListPaymentLinks = `-- name: ListPaymentLinks :many
+SELECT id, url, amount, currency, product_name, price_id, active, expires_at, max_uses, conversions, amount_collected, metadata, deactivated_at, created_at, updated_at, tenant_id FROM payment_links
+WHERE ($1 = FALSE OR active = $1) AND ($3 = '' OR tenant_id = $3)
+ORDER BY created_at DESC
+LIMIT $2
+`
+
+type ListPaymentLinksParams struct {
+	Active   bool   `json:"active"`
+	Limit    int32  `json:"limit"`
+	TenantID string `json:"tenant_id"`
+}
+
+func (q *Queries) ListPaymentLinks(ctx context.Context, db DBTX, arg ListPaymentLinksParams) ([]PaymentLink, error) {
+	rows, err := db.QueryContext(ctx, ListPaymentLinks, arg.Active, arg.Limit, arg.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []PaymentLink{}
+	for rows.Next() {
+		var i PaymentLink
+		if err := rows.Scan(
+			&i.ID,
+			&i.Url,
+			&i.Amount,
+			&i.Currency,
+			&i.ProductName,
+			&i.PriceID,
+			&i.Active,
+			&i.ExpiresAt,
+			&i.MaxUses,
+			&i.Conversions,
+			&i.AmountCollected,
+			&i.Metadata,
+			&i.DeactivatedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListPaymentMethods = `-- name: ListPaymentMethods :many
+SELECT id, type, customer_id, card_last4, card_brand, card_exp_month, card_exp_year, card_fingerprint, metadata, created_at, synced_at, tenant_id FROM payment_methods
+WHERE customer_id = $1 AND ($2 = '' OR tenant_id = $2)
+ORDER BY created_at DESC
+`
+
+type ListPaymentMethodsParams struct {
+	CustomerID string `json:"customer_id"`
+	TenantID   string `json:"tenant_id"`
+}
+
+func (q *Queries) ListPaymentMethods(ctx context.Context, db DBTX, arg ListPaymentMethodsParams) ([]PaymentMethod, error) {
+	rows, err := db.QueryContext(ctx, ListPaymentMethods, arg.CustomerID, arg.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []PaymentMethod{}
+	for rows.Next() {
+		var i PaymentMethod
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.CustomerID,
+			&i.CardLast4,
+			&i.CardBrand,
+			&i.CardExpMonth,
+			&i.CardExpYear,
+			&i.CardFingerprint,
+			&i.Metadata,
+			&i.CreatedAt,
+			&i.SyncedAt,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListPendingRefundApprovals = `-- name: ListPendingRefundApprovals :many
+SELECT id, tenant_id, api_key_id, operator_id, request, amount, reasons, status, decided_by, refund_id, created_at, updated_at, decided_at FROM refund_approvals
+WHERE tenant_id = $1 AND status = 'pending'
+ORDER BY created_at ASC
+`
+
+func (q *Queries) ListPendingRefundApprovals(ctx context.Context, db DBTX, tenantID string) ([]RefundApproval, error) {
+	rows, err := db.QueryContext(ctx, ListPendingRefundApprovals, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []RefundApproval{}
+	for rows.Next() {
+		var i RefundApproval
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.ApiKeyID,
+			&i.OperatorID,
+			&i.Request,
+			&i.Amount,
+			&i.Reasons,
+			&i.Status,
+			&i.DecidedBy,
+			&i.RefundID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DecidedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListProviderCredentialAudit = `-- name: ListProviderCredentialAudit :many
+SELECT id, tenant_id, provider, action, version, changed_fields, api_key_id, operator_id, created_at FROM provider_credential_audit
+WHERE tenant_id = $1 AND provider = $2
+ORDER BY created_at DESC
+LIMIT $3
+`
+
+type ListProviderCredentialAuditParams struct {
+	TenantID string `json:"tenant_id"`
+	Provider string `json:"provider"`
+	Limit    int32  `json:"limit"`
+}
+
+func (q *Queries) ListProviderCredentialAudit(ctx context.Context, db DBTX, arg ListProviderCredentialAuditParams) ([]ProviderCredentialAudit, error) {
+	rows, err := db.QueryContext(ctx, ListProviderCredentialAudit, arg.TenantID, arg.Provider, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ProviderCredentialAudit{}
+	for rows.Next() {
+		var i ProviderCredentialAudit
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.Provider,
+			&i.Action,
+			&i.Version,
+			&i.ChangedFields,
+			&i.ApiKeyID,
+			&i.OperatorID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListProviderCredentials = `-- name: ListProviderCredentials :many
+SELECT tenant_id, provider, ciphertext, masked_fields, version, verified_at, created_at, updated_at FROM provider_credentials
+WHERE tenant_id = $1
+ORDER BY provider
+`
+
+func (q *Queries) ListProviderCredentials(ctx context.Context, db DBTX, tenantID string) ([]ProviderCredential, error) {
+	rows, err := db.QueryContext(ctx, ListProviderCredentials, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ProviderCredential{}
+	for rows.Next() {
+		var i ProviderCredential
+		if err := rows.Scan(
+			&i.TenantID,
+			&i.Provider,
+			&i.Ciphertext,
+			&i.MaskedFields,
+			&i.Version,
+			&i.VerifiedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListQuarantineActivity = `-- name: ListQuarantineActivity :many
+SELECT id, quarantine_id, tenant_id, api_key_id, method, path, held_mutation_id, created_at FROM quarantine_activity
+WHERE quarantine_id = $1
+ORDER BY created_at DESC
+LIMIT $2
+`
+
+type ListQuarantineActivityParams struct {
+	QuarantineID string `json:"quarantine_id"`
+	Limit        int32  `json:"limit"`
+}
+
+func (q *Queries) ListQuarantineActivity(ctx context.Context, db DBTX, arg ListQuarantineActivityParams) ([]QuarantineActivity, error) {
+	rows, err := db.QueryContext(ctx, ListQuarantineActivity, arg.QuarantineID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []QuarantineActivity{}
+	for rows.Next() {
+		var i QuarantineActivity
+		if err := rows.Scan(
+			&i.ID,
+			&i.QuarantineID,
+			&i.TenantID,
+			&i.ApiKeyID,
+			&i.Method,
+			&i.Path,
+			&i.HeldMutationID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListReconciliationMismatches = `-- name: ListReconciliationMismatches :many
+SELECT id, run_id, kind, object_type, object_id, balance_transaction_id, provider_amount, local_amount, currency, detail, created_at FROM reconciliation_mismatches
+WHERE run_id = $1
+ORDER BY kind, object_id
+`
+
+func (q *Queries) ListReconciliationMismatches(ctx context.Context, db DBTX, runID string) ([]ReconciliationMismatch, error) {
+	rows, err := db.QueryContext(ctx, ListReconciliationMismatches, runID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ReconciliationMismatch{}
+	for rows.Next() {
+		var i ReconciliationMismatch
+		if err := rows.Scan(
+			&i.ID,
+			&i.RunID,
+			&i.Kind,
+			&i.ObjectType,
+			&i.ObjectID,
+			&i.BalanceTransactionID,
+			&i.ProviderAmount,
+			&i.LocalAmount,
+			&i.Currency,
+			&i.Detail,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListReconciliationRuns = `-- name: ListReconciliationRuns :many
+SELECT id, status, period_start, period_end, transactions_checked, payouts_checked, matched, mismatches, error, started_at, completed_at FROM reconciliation_runs
+ORDER BY started_at DESC
+LIMIT $1
+`
+
+func (q *Queries) ListReconciliationRuns(ctx context.Context, db DBTX, limit int32) ([]ReconciliationRun, error) {
+	rows, err := db.QueryContext(ctx, ListReconciliationRuns, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ReconciliationRun{}
+	for rows.Next() {
+		var i ReconciliationRun
+		if err := rows.Scan(
+			&i.ID,
+			&i.Status,
+			&i.PeriodStart,
+			&i.PeriodEnd,
+			&i.TransactionsChecked,
+			&i.PayoutsChecked,
+			&i.Matched,
+			&i.Mismatches,
+			&i.Error,
+			&i.StartedAt,
+			&i.CompletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListRefundBatchItems = `-- name: ListRefundBatchItems :many
+SELECT batch_id, item_index, status, amount, refund_id, approval_id, error, created_at FROM refund_batch_items
+WHERE batch_id = $1
+ORDER BY item_index
+`
+
+func (q *Queries) ListRefundBatchItems(ctx context.Context, db DBTX, batchID string) ([]RefundBatchItem, error) {
+	rows, err := db.QueryContext(ctx, ListRefundBatchItems, batchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []RefundBatchItem{}
+	for rows.Next() {
+		var i RefundBatchItem
+		if err := rows.Scan(
+			&i.BatchID,
+			&i.ItemIndex,
+			&i.Status,
+			&i.Amount,
+			&i.RefundID,
+			&i.ApprovalID,
+			&i.Error,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListRefunds = `-- name: ListRefunds :many
+SELECT id, charge_id, amount, currency, status, reason, metadata, created_at, updated_at, synced_at, tenant_id FROM refunds
+WHERE charge_id = $1 AND ($4 = '' OR tenant_id = $4)
+ORDER BY created_at DESC
+LIMIT $2 OFFSET $3
+`
+
+type ListRefundsParams struct {
+	ChargeID string `json:"charge_id"`
+	Limit    int32  `json:"limit"`
+	Offset   int32  `json:"offset"`
+	TenantID string `json:"tenant_id"`
+}
+
+func (q *Queries) ListRefunds(ctx context.Context, db DBTX, arg ListRefundsParams) ([]Refund, error) {
+	rows, err := db.QueryContext(ctx, ListRefunds,
+		arg.ChargeID,
+		arg.Limit,
+		arg.Offset,
+		arg.TenantID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Refund{}
+	for rows.Next() {
+		var i Refund
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChargeID,
+			&i.Amount,
+			&i.Currency,
+			&i.Status,
+			&i.Reason,
+			&i.Metadata,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.SyncedAt,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListRunnableOffboardingExports = `-- name: ListRunnableOffboardingExports :many
+SELECT id, tenant_id, status, recipient_public_key, manifest, archive_sha256, archive_size, error, pan_migration_status, pan_migration_destination, pan_migration_reference, pan_migration_requested_at, started_at, completed_at, created_at, updated_at FROM offboarding_exports
+WHERE status = 'pending' OR (status = 'running' AND started_at < NOW() - INTERVAL '1 hour')
+ORDER BY created_at
+LIMIT $1
+`
+
+func (q *Queries) ListRunnableOffboardingExports(ctx context.Context, db DBTX, limit int32) ([]OffboardingExport, error) {
+	rows, err := db.QueryContext(ctx, ListRunnableOffboardingExports, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []OffboardingExport{}
+	for rows.Next() {
+		var i OffboardingExport
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.Status,
+			&i.RecipientPublicKey,
+			&i.Manifest,
+			&i.ArchiveSha256,
+			&i.ArchiveSize,
+			&i.Error,
+			&i.PanMigrationStatus,
+			&i.PanMigrationDestination,
+			&i.PanMigrationReference,
+			&i.PanMigrationRequestedAt,
+			&i.StartedAt,
+			&i.CompletedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListRunnableRefundBatches = `-- name: ListRunnableRefundBatches :many
+SELECT id, tenant_id, api_key_id, operator_id, requests, status, scheduled_at, started_at, completed_at, created_at, updated_at FROM refund_batches
+WHERE (status = 'pending' AND scheduled_at <= $1) OR (status = 'running' AND started_at < NOW() - INTERVAL '1 hour')
+ORDER BY scheduled_at
+LIMIT $2
+`
+
+type ListRunnableRefundBatchesParams struct {
+	ScheduledAt time.Time `json:"scheduled_at"`
+	Limit       int32     `json:"limit"`
+}
+
+func (q *Queries) ListRunnableRefundBatches(ctx context.Context, db DBTX, arg ListRunnableRefundBatchesParams) ([]RefundBatch, error) {
+	rows, err := db.QueryContext(ctx, ListRunnableRefundBatches, arg.ScheduledAt, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []RefundBatch{}
+	for rows.Next() {
+		var i RefundBatch
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.ApiKeyID,
+			&i.OperatorID,
+			&i.Requests,
+			&i.Status,
+			&i.ScheduledAt,
+			&i.StartedAt,
+			&i.CompletedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListSimulatorObjects = `-- name: ListSimulatorObjects :many
+SELECT tenant_id, id, kind, parent_id, data, created_at, updated_at FROM simulator_objects
+WHERE tenant_id = $1
+  AND kind = $2
+  AND ($3::text = '' OR parent_id = $3::text)
+  AND ($4::text = '' OR (created_at, id) < (
+      SELECT cursor_object.created_at, cursor_object.id FROM simulator_objects cursor_object
+      WHERE cursor_object.tenant_id = $1 AND cursor_object.id = $4::text
+  ))
+ORDER BY created_at DESC, id DESC
+LIMIT $5
+`
+
+type ListSimulatorObjectsParams struct {
+	TenantID string `json:"tenant_id"`
+	Kind     string `json:"kind"`
+	ParentID string `json:"parent_id"`
+	Cursor   string `json:"cursor"`
+	Limit    int32  `json:"limit"`
+}
+
+func (q *Queries) ListSimulatorObjects(ctx context.Context, db DBTX, arg ListSimulatorObjectsParams) ([]SimulatorObject, error) {
+	rows, err := db.QueryContext(ctx, ListSimulatorObjects,
+		arg.TenantID,
+		arg.Kind,
+		arg.ParentID,
+		arg.Cursor,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SimulatorObject{}
+	for rows.Next() {
+		var i SimulatorObject
+		if err := rows.Scan(
+			&i.TenantID,
+			&i.ID,
+			&i.Kind,
+			&i.ParentID,
+			&i.Data,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListSmartRoutingRules = `-- name: ListSmartRoutingRules :many
+SELECT id, currency, bin_country, min_amount, max_amount, strategy, providers, pinned_provider, priority, enabled, description, updated_by, created_at, updated_at FROM smart_routing_rules
+ORDER BY priority, created_at
+`
+
+func (q *Queries) ListSmartRoutingRules(ctx context.Context, db DBTX) ([]SmartRoutingRule, error) {
+	rows, err := db.QueryContext(ctx, ListSmartRoutingRules)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SmartRoutingRule{}
+	for rows.Next() {
+		var i SmartRoutingRule
+		if err := rows.Scan(
+			&i.ID,
+			&i.Currency,
+			&i.BinCountry,
+			&i.MinAmount,
+			&i.MaxAmount,
+			&i.Strategy,
+			&i.Providers,
+			&i.PinnedProvider,
+			&i.Priority,
+			&i.Enabled,
+			&i.Description,
+			&i.UpdatedBy,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListStaleAuthorizations = `-- name: ListStaleAuthorizations :many
+SELECT charge_id, tenant_id, provider, amount, amount_captured, currency, captures, multi_capture, status, expires_at, closed_at, created_at, updated_at FROM authorizations
+WHERE status = 'open' AND expires_at < $1
+ORDER BY expires_at
+LIMIT $2
+`
+
+type ListStaleAuthorizationsParams struct {
+	ExpiresAt time.Time `json:"expires_at"`
+	Limit     int32     `json:"limit"`
+}
+
+func (q *Queries) ListStaleAuthorizations(ctx context.Context, db DBTX, arg ListStaleAuthorizationsParams) ([]Authorization, error) {
+	rows, err := db.QueryContext(ctx, ListStaleAuthorizations, arg.ExpiresAt, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Authorization{}
+	for rows.Next() {
+		var i Authorization
+		if err := rows.Scan(
+			&i.ChargeID,
+			&i.TenantID,
+			&i.Provider,
+			&i.Amount,
+			&i.AmountCaptured,
+			&i.Currency,
+			&i.Captures,
+			&i.MultiCapture,
+			&i.Status,
+			&i.ExpiresAt,
+			&i.ClosedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListSubscriptionPlans = `-- name: ListSubscriptionPlans :many
+SELECT id, product_id, nickname, amount, currency, billing_interval, interval_count, active, metadata, plan_created_at, synced_at, created_at, updated_at FROM subscription_plans
+WHERE ($1 = '' OR product_id = $1)
+ORDER BY plan_created_at DESC
+`
+
+func (q *Queries) ListSubscriptionPlans(ctx context.Context, db DBTX, productID string) ([]SubscriptionPlan, error) {
+	rows, err := db.QueryContext(ctx, ListSubscriptionPlans, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SubscriptionPlan{}
+	for rows.Next() {
+		var i SubscriptionPlan
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProductID,
+			&i.Nickname,
+			&i.Amount,
+			&i.Currency,
+			&i.BillingInterval,
+			&i.IntervalCount,
+			&i.Active,
+			&i.Metadata,
+			&i.PlanCreatedAt,
+			&i.SyncedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListSubscriptions = `-- name: ListSubscriptions :many
+SELECT id, customer_id, plan_id, status, paused, cancel_at_period_end, current_period_start, current_period_end, collection_method, days_until_due, metadata, subscription_created_at, synced_at, created_at, updated_at, tenant_id FROM subscriptions
+WHERE ($1 = '' OR customer_id = $1) AND ($2 = '' OR status = $2)
+ORDER BY subscription_created_at DESC
+LIMIT $3
+`
+
+type ListSubscriptionsParams struct {
+	CustomerID string `json:"customer_id"`
+	Status     string `json:"status"`
+	Limit      int32  `json:"limit"`
+}
+
+func (q *Queries) ListSubscriptions(ctx context.Context, db DBTX, arg ListSubscriptionsParams) ([]Subscription, error) {
+	rows, err := db.QueryContext(ctx, ListSubscriptions, arg.CustomerID, arg.Status, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Subscription{}
+	for rows.Next() {
+		var i Subscription
+		if err := rows.Scan(
+			&i.ID,
+			&i.CustomerID,
+			&i.PlanID,
+			&i.Status,
+			&i.Paused,
+			&i.CancelAtPeriodEnd,
+			&i.CurrentPeriodStart,
+			&i.CurrentPeriodEnd,
+			&i.CollectionMethod,
+			&i.DaysUntilDue,
+			&i.Metadata,
+			&i.SubscriptionCreatedAt,
+			&i.SyncedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListSucceededRefundsCreatedBetween = `-- name: ListSucceededRefundsCreatedBetween :many
+SELECT id, charge_id, amount, currency, status, reason, metadata, created_at, updated_at, synced_at, tenant_id FROM refunds
+WHERE status = 'succeeded' AND created_at >= $1 AND created_at < $2
+ORDER BY created_at
+`
+
+type ListSucceededRefundsCreatedBetweenParams struct {
+	CreatedFrom sql.NullTime `json:"created_from"`
+	CreatedTo   sql.NullTime `json:"created_to"`
+}
+
+func (q *Queries) ListSucceededRefundsCreatedBetween(ctx context.Context, db DBTX, arg ListSucceededRefundsCreatedBetweenParams) ([]Refund, error) {
+	rows, err := db.QueryContext(ctx, ListSucceededRefundsCreatedBetween, arg.CreatedFrom, arg.CreatedTo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Refund{}
+	for rows.Next() {
+		var i Refund
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChargeID,
+			&i.Amount,
+			&i.Currency,
+			&i.Status,
+			&i.Reason,
+			&i.Metadata,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.SyncedAt,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListTenantConfigs = `-- name: ListTenantConfigs :many
+SELECT tenant_id, name, status, default_provider, created_at, updated_at FROM tenant_configs
+ORDER BY tenant_id
+`
+
+func (q *Queries) ListTenantConfigs(ctx context.Context, db DBTX) ([]TenantConfig, error) {
+	rows, err := db.QueryContext(ctx, ListTenantConfigs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []TenantConfig{}
+	for rows.Next() {
+		var i TenantConfig
+		if err := rows.Scan(
+			&i.TenantID,
+			&i.Name,
+			&i.Status,
+			&i.DefaultProvider,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListTenantCustomerIDs = `-- name: ListTenantCustomerIDs :many
+SELECT customer_id FROM customer_identities
+WHERE tenant_id = $1
+ORDER BY created_at
+`
+
+func (q *Queries) ListTenantCustomerIDs(ctx context.Context, db DBTX, tenantID string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, ListTenantCustomerIDs, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var customer_id string
+		if err := rows.Scan(&customer_id); err != nil {
+			return nil, err
+		}
+		items = append(items, customer_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListTenantCustomers = `-- name: ListTenantCustomers :many
+SELECT id, email, name, phone, description, metadata, created_at, updated_at, synced_at, tenant_id FROM customers
+WHERE tenant_id = $1
+  AND ($2::text = '' OR lower(email) = $2::text)
+  AND ($3::text = '' OR lower(name) LIKE $3::text || '%')
+  AND ($4::jsonb = '{}'::jsonb OR metadata @> $4::jsonb)
+  AND ($5::timestamptz IS NULL OR created_at >= $5)
+  AND ($6::timestamptz IS NULL OR created_at < $6)
+  AND ($7::text = '' OR (created_at, id) < (
+      SELECT created_at, id FROM customers WHERE id = $7::text
+  ))
+  AND NOT EXISTS (SELECT 1 FROM customer_deletions WHERE customer_deletions.customer_id = customers.id)
+ORDER BY created_at DESC, id DESC
+LIMIT $8
+`
+
+type ListTenantCustomersParams struct {
+	TenantID      string          `json:"tenant_id"`
+	Email         string          `json:"email"`
+	NamePrefix    string          `json:"name_prefix"`
+	Metadata      json.RawMessage `json:"metadata"`
+	CreatedAfter  sql.NullTime    `json:"created_after"`
+	CreatedBefore sql.NullTime    `json:"created_before"`
+	StartingAfter string          `json:"starting_after"`
+	Limit         int32           `json:"limit"`
+}
+
+func (q *Queries) ListTenantCustomers(ctx context.Context, db DBTX, arg ListTenantCustomersParams) ([]Customer, error) {
+	rows, err := db.QueryContext(ctx, ListTenantCustomers,
+		arg.TenantID,
+		arg.Email,
+		arg.NamePrefix,
+		arg.Metadata,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.StartingAfter,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Customer{}
+	for rows.Next() {
+		var i Customer
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+			&i.Name,
+			&i.Phone,
+			&i.Description,
+			&i.Metadata,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.SyncedAt,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListTokenizedPaymentMethods = `-- name: ListTokenizedPaymentMethods :many
+SELECT payment_method_id FROM charge_credentials
+WHERE customer_id = $1 AND credential_type = 'network_token'
+    AND status = 'succeeded' AND payment_method_id <> ''
+GROUP BY payment_method_id
+`
+
+func (q *Queries) ListTokenizedPaymentMethods(ctx context.Context, db DBTX, customerID string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, ListTokenizedPaymentMethods, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var payment_method_id string
+		if err := rows.Scan(&payment_method_id); err != nil {
+			return nil, err
+		}
+		items = append(items, payment_method_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListUnreportedUsageRecords = `-- name: ListUnreportedUsageRecords :many
+SELECT id, subscription_id, subscription_item_id, price_id, quantity, action, recorded_at, idempotency_key, provider_record_id, reported_at, attempts, last_error, created_at, updated_at FROM usage_records
+WHERE reported_at IS NULL AND attempts < $1
+ORDER BY created_at
+LIMIT $2
+`
+
+type ListUnreportedUsageRecordsParams struct {
+	MaxAttempts int32 `json:"max_attempts"`
+	BatchSize   int32 `json:"batch_size"`
+}
+
+func (q *Queries) ListUnreportedUsageRecords(ctx context.Context, db DBTX, arg ListUnreportedUsageRecordsParams) ([]UsageRecord, error) {
+	rows, err := db.QueryContext(ctx, ListUnreportedUsageRecords, arg.MaxAttempts, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []UsageRecord{}
+	for rows.Next() {
+		var i UsageRecord
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubscriptionID,
+			&i.SubscriptionItemID,
+			&i.PriceID,
+			&i.Quantity,
+			&i.Action,
+			&i.RecordedAt,
+			&i.IdempotencyKey,
+			&i.ProviderRecordID,
+			&i.ReportedAt,
+			&i.Attempts,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListUsageRecords = `-- name: ListUsageRecords :many
+SELECT id, subscription_id, subscription_item_id, price_id, quantity, action, recorded_at, idempotency_key, provider_record_id, reported_at, attempts, last_error, created_at, updated_at FROM usage_records
+WHERE subscription_id = $1 AND recorded_at >= $2 AND recorded_at < $3
+ORDER BY recorded_at, created_at
+`
+
+type ListUsageRecordsParams struct {
+	SubscriptionID string    `json:"subscription_id"`
+	RecordedFrom   time.Time `json:"recorded_from"`
+	RecordedTo     time.Time `json:"recorded_to"`
+}
+
+func (q *Queries) ListUsageRecords(ctx context.Context, db DBTX, arg ListUsageRecordsParams) ([]UsageRecord, error) {
+	rows, err := db.QueryContext(ctx, ListUsageRecords, arg.SubscriptionID, arg.RecordedFrom, arg.RecordedTo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []UsageRecord{}
+	for rows.Next() {
+		var i UsageRecord
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubscriptionID,
+			&i.SubscriptionItemID,
+			&i.PriceID,
+			&i.Quantity,
+			&i.Action,
+			&i.RecordedAt,
+			&i.IdempotencyKey,
+			&i.ProviderRecordID,
+			&i.ReportedAt,
+			&i.Attempts,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListVaultTokensByCustomer = `-- name: ListVaultTokensByCustomer :many
+SELECT id, provider, provider_token, customer_id, type, fingerprint, created_at, updated_at, tenant_id FROM vault_tokens
+WHERE customer_id = $1 AND ($2 = '' OR tenant_id = $2)
+ORDER BY created_at
+`
+
+type ListVaultTokensByCustomerParams struct {
+	CustomerID string `json:"customer_id"`
+	TenantID   string `json:"tenant_id"`
+}
+
+func (q *Queries) ListVaultTokensByCustomer(ctx context.Context, db DBTX, arg ListVaultTokensByCustomerParams) ([]VaultToken, error) {
+	rows, err := db.QueryContext(ctx, ListVaultTokensByCustomer, arg.CustomerID, arg.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []VaultToken{}
+	for rows.Next() {
+		var i VaultToken
+		if err := rows.Scan(
+			&i.ID,
+			&i.Provider,
+			&i.ProviderToken,
+			&i.CustomerID,
+			&i.Type,
+			&i.Fingerprint,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListWebhookDeliveries = `-- name: ListWebhookDeliveries :many
+SELECT id, tenant_id, endpoint_id, event_id, event_type, payload, status, attempts, last_status_code, last_error, next_attempt_at, delivered_at, created_at, updated_at FROM webhook_deliveries
+WHERE tenant_id = $1 AND endpoint_id = $2 AND ($3 = '' OR status = $3)
+ORDER BY created_at DESC
+LIMIT $4
+`
+
+type ListWebhookDeliveriesParams struct {
+	TenantID   string `json:"tenant_id"`
+	EndpointID string `json:"endpoint_id"`
+	Status     string `json:"status"`
+	Limit      int32  `json:"limit"`
+}
+
+func (q *Queries) ListWebhookDeliveries(ctx context.Context, db DBTX, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	rows, err := db.QueryContext(ctx, ListWebhookDeliveries,
+		arg.TenantID,
+		arg.EndpointID,
+		arg.Status,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebhookDelivery{}
+	for rows.Next() {
+		var i WebhookDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.EndpointID,
+			&i.EventID,
+			&i.EventType,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.LastStatusCode,
+			&i.LastError,
+			&i.NextAttemptAt,
+			&i.DeliveredAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListWebhookDeliveryAttempts = `-- name: ListWebhookDeliveryAttempts :many
+SELECT id, delivery_id, status_code, response_body, error, duration_ms, manual, attempted_at FROM webhook_delivery_attempts
+WHERE delivery_id = $1
+ORDER BY attempted_at
+`
+
+func (q *Queries) ListWebhookDeliveryAttempts(ctx context.Context, db DBTX, deliveryID string) ([]WebhookDeliveryAttempt, error) {
+	rows, err := db.QueryContext(ctx, ListWebhookDeliveryAttempts, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebhookDeliveryAttempt{}
+	for rows.Next() {
+		var i WebhookDeliveryAttempt
+		if err := rows.Scan(
+			&i.ID,
+			&i.DeliveryID,
+			&i.StatusCode,
+			&i.ResponseBody,
+			&i.Error,
+			&i.DurationMs,
+			&i.Manual,
+			&i.AttemptedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListWebhookEndpoints = `-- name: ListWebhookEndpoints :many
+SELECT id, tenant_id, url, description, event_types, secret_ciphertext, enabled, created_at, updated_at FROM webhook_endpoints
+WHERE tenant_id = $1
+ORDER BY created_at
+`
+
+func (q *Queries) ListWebhookEndpoints(ctx context.Context, db DBTX, tenantID string) ([]WebhookEndpoint, error) {
+	rows, err := db.QueryContext(ctx, ListWebhookEndpoints, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebhookEndpoint{}
+	for rows.Next() {
+		var i WebhookEndpoint
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.Url,
+			&i.Description,
+			&i.EventTypes,
+			&i.SecretCiphertext,
+			&i.Enabled,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListWebhookSecretRotations = `-- name: ListWebhookSecretRotations :many
+SELECT id, status, old_endpoint_id, new_endpoint_id, new_secret, deliveries, confirmed_at, grace_ends_at, completed_at, cancelled_at, created_at, updated_at FROM webhook_secret_rotations
+ORDER BY created_at DESC
+LIMIT $1
+`
+
+func (q *Queries) ListWebhookSecretRotations(ctx context.Context, db DBTX, limit int32) ([]WebhookSecretRotation, error) {
+	rows, err := db.QueryContext(ctx, ListWebhookSecretRotations, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebhookSecretRotation{}
+	for rows.Next() {
+		var i WebhookSecretRotation
+		if err := rows.Scan(
+			&i.ID,
+			&i.Status,
+			&i.OldEndpointID,
+			&i.NewEndpointID,
+			&i.NewSecret,
+			&i.Deliveries,
+			&i.ConfirmedAt,
+			&i.GraceEndsAt,
+			&i.CompletedAt,
+			&i.CancelledAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const MarkCustomerEmailVerified = `-- name: MarkCustomerEmailVerified :one
+UPDATE customer_identities
+SET email_verified_at = $2,
+    verification_token_hash = '',
+    verification_expires_at = NULL
+WHERE customer_id = $1
+RETURNING customer_id, tenant_id, normalized_email, name, email_verified_at, verification_token_hash, verification_expires_at, created_at, updated_at
+`
+
+type MarkCustomerEmailVerifiedParams struct {
+	CustomerID      string       `json:"customer_id"`
+	EmailVerifiedAt sql.NullTime `json:"email_verified_at"`
+}
+
+func (q *Queries) MarkCustomerEmailVerified(ctx context.Context, db DBTX, arg MarkCustomerEmailVerifiedParams) (CustomerIdentity, error) {
+	row := db.QueryRowContext(ctx, MarkCustomerEmailVerified, arg.CustomerID, arg.EmailVerifiedAt)
+	var i CustomerIdentity
+	err := row.Scan(
+		&i.CustomerID,
+		&i.TenantID,
+		&i.NormalizedEmail,
+		&i.Name,
+		&i.EmailVerifiedAt,
+		&i.VerificationTokenHash,
+		&i.VerificationExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const MarkDisputeEvidenceSubmitted = `-- name: MarkDisputeEvidenceSubmitted :one
+UPDATE dispute_evidence
+SET submitted_by = $2,
+    submitted_at = NOW()
+WHERE dispute_id = $1
+RETURNING dispute_id, fields, updated_by, submitted_by, submitted_at, created_at, updated_at
+`
+
+type MarkDisputeEvidenceSubmittedParams struct {
+	DisputeID   string `json:"dispute_id"`
+	SubmittedBy string `json:"submitted_by"`
+}
+
+func (q *Queries) MarkDisputeEvidenceSubmitted(ctx context.Context, db DBTX, arg MarkDisputeEvidenceSubmittedParams) (DisputeEvidence, error) {
+	row := db.QueryRowContext(ctx, MarkDisputeEvidenceSubmitted, arg.DisputeID, arg.SubmittedBy)
+	var i DisputeEvidence
+	err := row.Scan(
+		&i.DisputeID,
+		&i.Fields,
+		&i.UpdatedBy,
+		&i.SubmittedBy,
+		&i.SubmittedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const MarkMirroredChargeDisputed = `-- name: MarkMirroredChargeDisputed :exec
+UPDATE charges
+SET disputed = TRUE
+WHERE id = $1
+`
+
+func (q *Queries) MarkMirroredChargeDisputed(ctx context.Context, db DBTX, id string) error {
+	_, err := db.ExecContext(ctx, MarkMirroredChargeDisputed, id)
+	return err
+}
+
+const MarkReceivableInvoiceOverdue = `-- name: MarkReceivableInvoiceOverdue :exec
+UPDATE receivable_invoices
+SET overdue_at = $2
+WHERE invoice_id = $1 AND overdue_at IS NULL
+`
+
+type MarkReceivableInvoiceOverdueParams struct {
+	InvoiceID string       `json:"invoice_id"`
+	OverdueAt sql.NullTime `json:"overdue_at"`
+}
+
+func (q *Queries) MarkReceivableInvoiceOverdue(ctx context.Context, db DBTX, arg MarkReceivableInvoiceOverdueParams) error {
+	_, err := db.ExecContext(ctx, MarkReceivableInvoiceOverdue, arg.InvoiceID, arg.OverdueAt)
+	return err
+}
+
+const MarkReceivableInvoicePaid = `-- name: MarkReceivableInvoicePaid :one
+UPDATE receivable_invoices
+SET status = 'paid', amount_remaining = 0, paid_at = $2, paid_reference = $3
+WHERE invoice_id = $1
+RETURNING invoice_id, customer_id, subscription_id, number, amount_due, amount_remaining, currency, due_date, status, overdue_at, paid_at, paid_reference, created_at, updated_at
+`
+
+type MarkReceivableInvoicePaidParams struct {
+	InvoiceID     string       `json:"invoice_id"`
+	PaidAt        sql.NullTime `json:"paid_at"`
+	PaidReference string       `json:"paid_reference"`
+}
+
+func (q *Queries) MarkReceivableInvoicePaid(ctx context.Context, db DBTX, arg MarkReceivableInvoicePaidParams) (ReceivableInvoice, error) {
+	row := db.QueryRowContext(ctx, MarkReceivableInvoicePaid, arg.InvoiceID, arg.PaidAt, arg.PaidReference)
+	var i ReceivableInvoice
+	err := row.Scan(
+		&i.InvoiceID,
+		&i.CustomerID,
+		&i.SubscriptionID,
+		&i.Number,
+		&i.AmountDue,
+		&i.AmountRemaining,
+		&i.Currency,
+		&i.DueDate,
+		&i.Status,
+		&i.OverdueAt,
+		&i.PaidAt,
+		&i.PaidReference,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const MarkUsageRecordReported = `-- name: MarkUsageRecordReported :one
+UPDATE usage_records
+SET reported_at = $2, provider_record_id = $3, attempts = attempts + 1, last_error = ''
+WHERE id = $1
+RETURNING id, subscription_id, subscription_item_id, price_id, quantity, action, recorded_at, idempotency_key, provider_record_id, reported_at, attempts, last_error, created_at, updated_at
+`
+
+type MarkUsageRecordReportedParams struct {
+	ID               string       `json:"id"`
+	ReportedAt       sql.NullTime `json:"reported_at"`
+	ProviderRecordID string       `json:"provider_record_id"`
+}
+
+func (q *Queries) MarkUsageRecordReported(ctx context.Context, db DBTX, arg MarkUsageRecordReportedParams) (UsageRecord, error) {
+	row := db.QueryRowContext(ctx, MarkUsageRecordReported, arg.ID, arg.ReportedAt, arg.ProviderRecordID)
+	var i UsageRecord
+	err := row.Scan(
+		&i.ID,
+		&i.SubscriptionID,
+		&i.SubscriptionItemID,
+		&i.PriceID,
+		&i.Quantity,
+		&i.Action,
+		&i.RecordedAt,
+		&i.IdempotencyKey,
+		&i.ProviderRecordID,
+		&i.ReportedAt,
+		&i.Attempts,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const MatchFraudListEntries = `-- name: MatchFraudListEntries :many
+SELECT id, list, entry_type, value, reason, created_by, created_at FROM fraud_list_entries
+WHERE (entry_type = 'customer' AND value = $1::text)
+   OR (entry_type = 'card_fingerprint' AND value = $2::text)
+   OR (entry_type = 'ip_address' AND value = $3::text)
+ORDER BY list, created_at
+`
+
+type MatchFraudListEntriesParams struct {
+	CustomerID      string `json:"customer_id"`
+	CardFingerprint string `json:"card_fingerprint"`
+	IpAddress       string `json:"ip_address"`
+}
+
+func (q *Queries) MatchFraudListEntries(ctx context.Context, db DBTX, arg MatchFraudListEntriesParams) ([]FraudListEntry, error) {
+	rows, err := db.QueryContext(ctx, MatchFraudListEntries, arg.CustomerID, arg.CardFingerprint, arg.IpAddress)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []FraudListEntry{}
+	for rows.Next() {
+		var i FraudListEntry
+		if err := rows.Scan(
+			&i.ID,
+			&i.List,
+			&i.EntryType,
+			&i.Value,
+			&i.Reason,
+			&i.CreatedBy,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const OpenDunningCase = `-- name: OpenDunningCase :execrows
+INSERT INTO dunning_cases (
+    id, invoice_id, subscription_id, customer_id, amount_due, currency, state, next_retry_at, last_error, failed_at, tenant_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10,
+    COALESCE(NULLIF($11, ''), (SELECT tenant_id FROM customers WHERE customers.id = $4), 'default')
+)
+ON CONFLICT DO NOTHING
+`
+
+type OpenDunningCaseParams struct {
+	ID             string       `json:"id"`
+	InvoiceID      string       `json:"invoice_id"`
+	SubscriptionID string       `json:"subscription_id"`
+	CustomerID     string       `json:"customer_id"`
+	AmountDue      int64        `json:"amount_due"`
+	Currency       string       `json:"currency"`
+	State          string       `json:"state"`
+	NextRetryAt    sql.NullTime `json:"next_retry_at"`
+	LastError      string       `json:"last_error"`
+	FailedAt       time.Time    `json:"failed_at"`
+	TenantID       string       `json:"tenant_id"`
+}
+
+func (q *Queries) OpenDunningCase(ctx context.Context, db DBTX, arg OpenDunningCaseParams) (int64, error) {
+	result, err := db.ExecContext(ctx, OpenDunningCase,
+		arg.ID,
+		arg.InvoiceID,
+		arg.SubscriptionID,
+		arg.CustomerID,
+		arg.AmountDue,
+		arg.Currency,
+		arg.State,
+		arg.NextRetryAt,
+		arg.LastError,
+		arg.FailedAt,
+		arg.TenantID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const PurgeArchivedEvents = `-- name: PurgeArchivedEvents :execrows
+DELETE FROM event_archive
+WHERE occurred_at < $1
+`
+
+func (q *Queries) PurgeArchivedEvents(ctx context.Context, db DBTX, occurredAt time.Time) (int64, error) {
+	result, err := db.ExecContext(ctx, PurgeArchivedEvents, occurredAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const PurgeDeadLetters = `-- name: PurgeDeadLetters :execrows
+DELETE FROM dlq_events
+WHERE status = $1 AND created_at < $2
+`
+
+type PurgeDeadLettersParams struct {
+	Status    string       `json:"status"`
+	CreatedAt sql.NullTime `json:"created_at"`
+}
+
+func (q *Queries) PurgeDeadLetters(ctx context.Context, db DBTX, arg PurgeDeadLettersParams) (int64, error) {
+	result, err := db.ExecContext(ctx, PurgeDeadLetters, arg.Status, arg.CreatedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const RecordBudgetAlert = `-- name: RecordBudgetAlert :execrows
+INSERT INTO tenant_budget_alerts (
+    tenant_id, period, threshold
+) VALUES (
+    $1, $2, $3
+)
+ON CONFLICT (tenant_id, period, threshold) DO NOTHING
+`
+
+type RecordBudgetAlertParams struct {
+	TenantID  string `json:"tenant_id"`
+	Period    string `json:"period"`
+	Threshold int32  `json:"threshold"`
+}
+
+func (q *Queries) RecordBudgetAlert(ctx context.Context, db DBTX, arg RecordBudgetAlertParams) (int64, error) {
+	result, err := db.ExecContext(ctx, RecordBudgetAlert, arg.TenantID, arg.Period, arg.Threshold)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const RecordChargeCredential = `-- name: RecordChargeCredential :exec
+INSERT INTO charge_credentials (
+    charge_id, tenant_id, customer_id, payment_method_id, network, credential_type,
+    wallet, amount, currency, status, failure_code
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+)
+ON CONFLICT (charge_id) DO UPDATE SET
+    status = EXCLUDED.status,
+    failure_code = EXCLUDED.failure_code
+`
+
+type RecordChargeCredentialParams struct {
+	ChargeID        string `json:"charge_id"`
+	TenantID        string `json:"tenant_id"`
+	CustomerID      string `json:"customer_id"`
+	PaymentMethodID string `json:"payment_method_id"`
+	Network         string `json:"network"`
+	CredentialType  string `json:"credential_type"`
+	Wallet          string `json:"wallet"`
+	Amount          int64  `json:"amount"`
+	Currency        string `json:"currency"`
+	Status          string `json:"status"`
+	FailureCode     string `json:"failure_code"`
+}
+
+func (q *Queries) RecordChargeCredential(ctx context.Context, db DBTX, arg RecordChargeCredentialParams) error {
+	_, err := db.ExecContext(ctx, RecordChargeCredential,
+		arg.ChargeID,
+		arg.TenantID,
+		arg.CustomerID,
+		arg.PaymentMethodID,
+		arg.Network,
+		arg.CredentialType,
+		arg.Wallet,
+		arg.Amount,
+		arg.Currency,
+		arg.Status,
+		arg.FailureCode,
+	)
+	return err
+}
+
+const RecordCustomerErasure = `-- name: RecordCustomerErasure :one
+INSERT INTO customer_erasures (
+    customer_id, tenant_id, erased_at
+) VALUES (
+    $1, $2, $3
+)
+ON CONFLICT (customer_id) DO UPDATE
+SET customer_id = EXCLUDED.customer_id
+RETURNING customer_id, tenant_id, erased_at, created_at
+`
+
+type RecordCustomerErasureParams struct {
+	CustomerID string    `json:"customer_id"`
+	TenantID   string    `json:"tenant_id"`
+	ErasedAt   time.Time `json:"erased_at"`
+}
+
+func (q *Queries) RecordCustomerErasure(ctx context.Context, db DBTX, arg RecordCustomerErasureParams) (CustomerErasure, error) {
+	row := db.QueryRowContext(ctx, RecordCustomerErasure, arg.CustomerID, arg.TenantID, arg.ErasedAt)
+	var i CustomerErasure
+	err := row.Scan(
+		&i.CustomerID,
+		&i.TenantID,
+		&i.ErasedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const RecordDeprecatedUsage = `-- name: RecordDeprecatedUsage :exec
+INSERT INTO deprecated_usage (
+    notice_id, api_key_id, request_count, first_seen, last_seen
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (notice_id, api_key_id) DO UPDATE SET
+    request_count = deprecated_usage.request_count + EXCLUDED.request_count,
+    first_seen = LEAST(deprecated_usage.first_seen, EXCLUDED.first_seen),
+    last_seen = GREATEST(deprecated_usage.last_seen, EXCLUDED.last_seen)
+`
+
+type RecordDeprecatedUsageParams struct {
+	NoticeID     string    `json:"notice_id"`
+	ApiKeyID     string    `json:"api_key_id"`
+	RequestCount int64     `json:"request_count"`
+	FirstSeen    time.Time `json:"first_seen"`
+	LastSeen     time.Time `json:"last_seen"`
+}
+
+func (q *Queries) RecordDeprecatedUsage(ctx context.Context, db DBTX, arg RecordDeprecatedUsageParams) error {
+	_, err := db.ExecContext(ctx, RecordDeprecatedUsage,
+		arg.NoticeID,
+		arg.ApiKeyID,
+		arg.RequestCount,
+		arg.FirstSeen,
+		arg.LastSeen,
+	)
+	return err
+}
+
+const RecordEntityVersion = `-- name: RecordEntityVersion :exec
+INSERT INTO entity_versions (
+    entity_type, entity_id, status, state, event_id, event_type, valid_from
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+ON CONFLICT (entity_type, entity_id, event_id) DO NOTHING
+`
+
+type RecordEntityVersionParams struct {
+	EntityType string          `json:"entity_type"`
+	EntityID   string          `json:"entity_id"`
+	Status     string          `json:"status"`
+	State      json.RawMessage `json:"state"`
+	EventID    string          `json:"event_id"`
+	EventType  string          `json:"event_type"`
+	ValidFrom  time.Time       `json:"valid_from"`
+}
+
+func (q *Queries) RecordEntityVersion(ctx context.Context, db DBTX, arg RecordEntityVersionParams) error {
+	_, err := db.ExecContext(ctx, RecordEntityVersion,
+		arg.EntityType,
+		arg.EntityID,
+		arg.Status,
+		arg.State,
+		arg.EventID,
+		arg.EventType,
+		arg.ValidFrom,
+	)
+	return err
+}
+
+const RecordFraudReviewCharge = `-- name: RecordFraudReviewCharge :one
+UPDATE fraud_reviews
+SET status = $2,
+    charge_id = $3,
+    failure_reason = $4
+WHERE id = $1
+RETURNING id, tenant_id, screening_id, customer_id, amount, currency, request, findings, status, decided_by, charge_id, failure_reason, decided_at, created_at, updated_at
+`
+
+type RecordFraudReviewChargeParams struct {
+	ID            string `json:"id"`
+	Status        string `json:"status"`
+	ChargeID      string `json:"charge_id"`
+	FailureReason string `json:"failure_reason"`
+}
+
+func (q *Queries) RecordFraudReviewCharge(ctx context.Context, db DBTX, arg RecordFraudReviewChargeParams) (FraudReview, error) {
+	row := db.QueryRowContext(ctx, RecordFraudReviewCharge,
+		arg.ID,
+		arg.Status,
+		arg.ChargeID,
+		arg.FailureReason,
+	)
+	var i FraudReview
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.ScreeningID,
+		&i.CustomerID,
+		&i.Amount,
+		&i.Currency,
+		&i.Request,
+		&i.Findings,
+		&i.Status,
+		&i.DecidedBy,
+		&i.ChargeID,
+		&i.FailureReason,
+		&i.DecidedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const RecordFraudScreening = `-- name: RecordFraudScreening :exec
+INSERT INTO fraud_screenings (
+    id, tenant_id, customer_id, card_fingerprint, ip_address,
+    amount, currency, decision, findings
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+`
+
+type RecordFraudScreeningParams struct {
+	ID              string          `json:"id"`
+	TenantID        string          `json:"tenant_id"`
+	CustomerID      string          `json:"customer_id"`
+	CardFingerprint string          `json:"card_fingerprint"`
+	IpAddress       string          `json:"ip_address"`
+	Amount          int64           `json:"amount"`
+	Currency        string          `json:"currency"`
+	Decision        string          `json:"decision"`
+	Findings        json.RawMessage `json:"findings"`
+}
+
+func (q *Queries) RecordFraudScreening(ctx context.Context, db DBTX, arg RecordFraudScreeningParams) error {
+	_, err := db.ExecContext(ctx, RecordFraudScreening,
+		arg.ID,
+		arg.TenantID,
+		arg.CustomerID,
+		arg.CardFingerprint,
+		arg.IpAddress,
+		arg.Amount,
+		arg.Currency,
+		arg.Decision,
+		arg.Findings,
+	)
+	return err
+}
+
+const RecordHeldMutationResult = `-- name: RecordHeldMutationResult :one
+UPDATE held_mutations
+SET status = $2, response_status = $3, response_body = $4
+WHERE id = $1
+RETURNING id, quarantine_id, tenant_id, api_key_id, operator_id, method, path, content_type, body, status, decided_by, decided_at, response_status, response_body, created_at, updated_at
+`
+
+type RecordHeldMutationResultParams struct {
+	ID             string `json:"id"`
+	Status         string `json:"status"`
+	ResponseStatus int32  `json:"response_status"`
+	ResponseBody   string `json:"response_body"`
+}
+
+func (q *Queries) RecordHeldMutationResult(ctx context.Context, db DBTX, arg RecordHeldMutationResultParams) (HeldMutation, error) {
+	row := db.QueryRowContext(ctx, RecordHeldMutationResult,
+		arg.ID,
+		arg.Status,
+		arg.ResponseStatus,
+		arg.ResponseBody,
+	)
+	var i HeldMutation
+	err := row.Scan(
+		&i.ID,
+		&i.QuarantineID,
+		&i.TenantID,
+		&i.ApiKeyID,
+		&i.OperatorID,
+		&i.Method,
+		&i.Path,
+		&i.ContentType,
+		&i.Body,
+		&i.Status,
+		&i.DecidedBy,
+		&i.DecidedAt,
+		&i.ResponseStatus,
+		&i.ResponseBody,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const RecordInvoiceReminder = `-- name: RecordInvoiceReminder :exec
+INSERT INTO invoice_reminders (
+    invoice_id, offset_days
+) VALUES (
+    $1, $2
+)
+ON CONFLICT (invoice_id, offset_days) DO NOTHING
+`
+
+type RecordInvoiceReminderParams struct {
+	InvoiceID  string `json:"invoice_id"`
+	OffsetDays int32  `json:"offset_days"`
+}
+
+func (q *Queries) RecordInvoiceReminder(ctx context.Context, db DBTX, arg RecordInvoiceReminderParams) error {
+	_, err := db.ExecContext(ctx, RecordInvoiceReminder, arg.InvoiceID, arg.OffsetDays)
+	return err
+}
+
+const RecordOffboardingPANMigration = `-- name: RecordOffboardingPANMigration :one
+UPDATE offboarding_exports
+SET pan_migration_status = $2, pan_migration_destination = $3, pan_migration_reference = $4, pan_migration_requested_at = NOW()
+WHERE id = $1 AND status = 'completed'
+RETURNING id, tenant_id, status, recipient_public_key, manifest, archive_sha256, archive_size, error, pan_migration_status, pan_migration_destination, pan_migration_reference, pan_migration_requested_at, started_at, completed_at, created_at, updated_at
+`
+
+type RecordOffboardingPANMigrationParams struct {
+	ID                      string `json:"id"`
+	PanMigrationStatus      string `json:"pan_migration_status"`
+	PanMigrationDestination string `json:"pan_migration_destination"`
+	PanMigrationReference   string `json:"pan_migration_reference"`
+}
+
+func (q *Queries) RecordOffboardingPANMigration(ctx context.Context, db DBTX, arg RecordOffboardingPANMigrationParams) (OffboardingExport, error) {
+	row := db.QueryRowContext(ctx, RecordOffboardingPANMigration,
+		arg.ID,
+		arg.PanMigrationStatus,
+		arg.PanMigrationDestination,
+		arg.PanMigrationReference,
+	)
+	var i OffboardingExport
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.Status,
+		&i.RecipientPublicKey,
+		&i.Manifest,
+		&i.ArchiveSha256,
+		&i.ArchiveSize,
+		&i.Error,
+		&i.PanMigrationStatus,
+		&i.PanMigrationDestination,
+		&i.PanMigrationReference,
+		&i.PanMigrationRequestedAt,
+		&i.StartedAt,
+		&i.CompletedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const RecordQuarantineActivity = `-- name: RecordQuarantineActivity :exec
+INSERT INTO quarantine_activity (
+    id, quarantine_id, tenant_id, api_key_id, method, path, held_mutation_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+`
+
+type RecordQuarantineActivityParams struct {
+	ID             string `json:"id"`
+	QuarantineID   string `json:"quarantine_id"`
+	TenantID       string `json:"tenant_id"`
+	ApiKeyID       string `json:"api_key_id"`
+	Method         string `json:"method"`
+	Path           string `json:"path"`
+	HeldMutationID string `json:"held_mutation_id"`
+}
+
+func (q *Queries) RecordQuarantineActivity(ctx context.Context, db DBTX, arg RecordQuarantineActivityParams) error {
+	_, err := db.ExecContext(ctx, RecordQuarantineActivity,
+		arg.ID,
+		arg.QuarantineID,
+		arg.TenantID,
+		arg.ApiKeyID,
+		arg.Method,
+		arg.Path,
+		arg.HeldMutationID,
+	)
+	return err
+}
+
+const RecordRadarScore = `-- name: RecordRadarScore :exec
+INSERT INTO fraud_radar_scores (
+    charge_id, customer_id, card_fingerprint, risk_score, risk_level
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (charge_id) DO UPDATE
+SET risk_score = EXCLUDED.risk_score,
+    risk_level = EXCLUDED.risk_level
+`
+
+type RecordRadarScoreParams struct {
+	ChargeID        string `json:"charge_id"`
+	CustomerID      string `json:"customer_id"`
+	CardFingerprint string `json:"card_fingerprint"`
+	RiskScore       int32  `json:"risk_score"`
+	RiskLevel       string `json:"risk_level"`
+}
+
+func (q *Queries) RecordRadarScore(ctx context.Context, db DBTX, arg RecordRadarScoreParams) error {
+	_, err := db.ExecContext(ctx, RecordRadarScore,
+		arg.ChargeID,
+		arg.CustomerID,
+		arg.CardFingerprint,
+		arg.RiskScore,
+		arg.RiskLevel,
+	)
+	return err
+}
+
+const RecordRefundActivity = `-- name: RecordRefundActivity :exec
+INSERT INTO refund_activity (
+    id, tenant_id, api_key_id, operator_id, refund_id, charge_id, amount, currency
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+`
+
+type RecordRefundActivityParams struct {
+	ID         string `json:"id"`
+	TenantID   string `json:"tenant_id"`
+	ApiKeyID   string `json:"api_key_id"`
+	OperatorID string `json:"operator_id"`
+	RefundID   string `json:"refund_id"`
+	ChargeID   string `json:"charge_id"`
+	Amount     int64  `json:"amount"`
+	Currency   string `json:"currency"`
+}
+
+func (q *Queries) RecordRefundActivity(ctx context.Context, db DBTX, arg RecordRefundActivityParams) error {
+	_, err := db.ExecContext(ctx, RecordRefundActivity,
+		arg.ID,
+		arg.TenantID,
+		arg.ApiKeyID,
+		arg.OperatorID,
+		arg.RefundID,
+		arg.ChargeID,
+		arg.Amount,
+		arg.Currency,
+	)
+	return err
+}
+
+const RecordRefundBatchItem = `-- name: RecordRefundBatchItem :exec
+INSERT INTO refund_batch_items (
+    batch_id, item_index, status, amount, refund_id, approval_id, error
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+ON CONFLICT (batch_id, item_index) DO UPDATE
+SET status = EXCLUDED.status, amount = EXCLUDED.amount, refund_id = EXCLUDED.refund_id, approval_id = EXCLUDED.approval_id, error = EXCLUDED.error
+`
+
+type RecordRefundBatchItemParams struct {
+	BatchID    string `json:"batch_id"`
+	ItemIndex  int32  `json:"item_index"`
+	Status     string `json:"status"`
+	Amount     int64  `json:"amount"`
+	RefundID   string `json:"refund_id"`
+	ApprovalID string `json:"approval_id"`
+	Error      string `json:"error"`
+}
+
+func (q *Queries) RecordRefundBatchItem(ctx context.Context, db DBTX, arg RecordRefundBatchItemParams) error {
+	_, err := db.ExecContext(ctx, RecordRefundBatchItem,
+		arg.BatchID,
+		arg.ItemIndex,
+		arg.Status,
+		arg.Amount,
+		arg.RefundID,
+		arg.ApprovalID,
+		arg.Error,
+	)
+	return err
+}
+
+const RecordRoutedCharge = `-- name: RecordRoutedCharge :exec
+INSERT INTO routed_charges (
+    charge_id, provider, currency, amount, settlement_currency, reason
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+ON CONFLICT (charge_id) DO NOTHING
+`
+
+type RecordRoutedChargeParams struct {
+	ChargeID           string `json:"charge_id"`
+	Provider           string `json:"provider"`
+	Currency           string `json:"currency"`
+	Amount             int64  `json:"amount"`
+	SettlementCurrency string `json:"settlement_currency"`
+	Reason             string `json:"reason"`
+}
+
+func (q *Queries) RecordRoutedCharge(ctx context.Context, db DBTX, arg RecordRoutedChargeParams) error {
+	_, err := db.ExecContext(ctx, RecordRoutedCharge,
+		arg.ChargeID,
+		arg.Provider,
+		arg.Currency,
+		arg.Amount,
+		arg.SettlementCurrency,
+		arg.Reason,
+	)
+	return err
+}
+
+const RecordUsageReportFailure = `-- name: RecordUsageReportFailure :exec
+UPDATE usage_records
+SET attempts = attempts + 1, last_error = $2
+WHERE id = $1
+`
+
+type RecordUsageReportFailureParams struct {
+	ID        string `json:"id"`
+	LastError string `json:"last_error"`
+}
+
+func (q *Queries) RecordUsageReportFailure(ctx context.Context, db DBTX, arg RecordUsageReportFailureParams) error {
+	_, err := db.ExecContext(ctx, RecordUsageReportFailure, arg.ID, arg.LastError)
+	return err
+}
+
+const RecordWebhookEvent = `-- name: RecordWebhookEvent :exec
+INSERT INTO webhook_events (
+    id, type, created, source, payload
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (id) DO NOTHING
+`
+
+type RecordWebhookEventParams struct {
+	ID      string                `json:"id"`
+	Type    string                `json:"type"`
+	Created int64                 `json:"created"`
+	Source  string                `json:"source"`
+	Payload pqtype.NullRawMessage `json:"payload"`
+}
+
+func (q *Queries) RecordWebhookEvent(ctx context.Context, db DBTX, arg RecordWebhookEventParams) error {
+	_, err := db.ExecContext(ctx, RecordWebhookEvent,
+		arg.ID,
+		arg.Type,
+		arg.Created,
+		arg.Source,
+		arg.Payload,
+	)
+	return err
+}
+
+const RecordWebhookSecretRotationDelivery = `-- name: RecordWebhookSecretRotationDelivery :one
+UPDATE webhook_secret_rotations
+SET deliveries = deliveries + 1, confirmed_at = COALESCE(confirmed_at, NOW())
+WHERE id = $1 AND status = 'pending'
+RETURNING id, status, old_endpoint_id, new_endpoint_id, new_secret, deliveries, confirmed_at, grace_ends_at, completed_at, cancelled_at, created_at, updated_at
+`
+
+func (q *Queries) RecordWebhookSecretRotationDelivery(ctx context.Context, db DBTX, id string) (WebhookSecretRotation, error) {
+	row := db.QueryRowContext(ctx, RecordWebhookSecretRotationDelivery, id)
+	var i WebhookSecretRotation
+	err := row.Scan(
+		&i.ID,
+		&i.Status,
+		&i.OldEndpointID,
+		&i.NewEndpointID,
+		&i.NewSecret,
+		&i.Deliveries,
+		&i.ConfirmedAt,
+		&i.GraceEndsAt,
+		&i.CompletedAt,
+		&i.CancelledAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const ReleaseCustomerDeletion = `-- name: ReleaseCustomerDeletion :exec
+UPDATE customer_deletions
+SET purged_at = NULL
+WHERE customer_id = $1
+`
+
+func (q *Queries) ReleaseCustomerDeletion(ctx context.Context, db DBTX, customerID string) error {
+	_, err := db.ExecContext(ctx, ReleaseCustomerDeletion, customerID)
+	return err
+}
+
+const ReleaseCustomerHold = `-- name: ReleaseCustomerHold :one
+UPDATE customer_holds
+SET status = 'released', release_reason = $2, released_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND status = 'active' AND ($3 = '' OR tenant_id = $3)
+RETURNING id, tenant_id, customer_id, reason, source_id, status, blocks_charges, paused_subscriptions, release_reason, created_at, updated_at, released_at
+`
+
+type ReleaseCustomerHoldParams struct {
+	ID            string         `json:"id"`
+	ReleaseReason sql.NullString `json:"release_reason"`
+	TenantID      string         `json:"tenant_id"`
+}
+
+func (q *Queries) ReleaseCustomerHold(ctx context.Context, db DBTX, arg ReleaseCustomerHoldParams) (CustomerHold, error) {
+	row := db.QueryRowContext(ctx, ReleaseCustomerHold, arg.ID, arg.ReleaseReason, arg.TenantID)
+	var i CustomerHold
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.CustomerID,
+		&i.Reason,
+		&i.SourceID,
+		&i.Status,
+		&i.BlocksCharges,
+		&i.PausedSubscriptions,
+		&i.ReleaseReason,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ReleasedAt,
+	)
+	return i, err
+}
+
+const ReleaseRefundApproval = `-- name: ReleaseRefundApproval :exec
+UPDATE refund_approvals
+SET status = 'pending', decided_by = NULL, updated_at = NOW()
+WHERE id = $1 AND status = 'approving' AND ($2 = '' OR tenant_id = $2)
+`
+
+type ReleaseRefundApprovalParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenant_id"`
+}
+
+func (q *Queries) ReleaseRefundApproval(ctx context.Context, db DBTX, arg ReleaseRefundApprovalParams) error {
+	_, err := db.ExecContext(ctx, ReleaseRefundApproval, arg.ID, arg.TenantID)
+	return err
+}
+
+const RemapVaultToken = `-- name: RemapVaultToken :one
+UPDATE vault_tokens
+SET provider = $2, provider_token = $3
+WHERE id = $1
+RETURNING id, provider, provider_token, customer_id, type, fingerprint, created_at, updated_at, tenant_id
+`
+
+type RemapVaultTokenParams struct {
+	ID            string `json:"id"`
+	Provider      string `json:"provider"`
+	ProviderToken string `json:"provider_token"`
+}
+
+func (q *Queries) RemapVaultToken(ctx context.Context, db DBTX, arg RemapVaultTokenParams) (VaultToken, error) {
+	row := db.QueryRowContext(ctx, RemapVaultToken, arg.ID, arg.Provider, arg.ProviderToken)
+	var i VaultToken
+	err := row.Scan(
+		&i.ID,
+		&i.Provider,
+		&i.ProviderToken,
+		&i.CustomerID,
+		&i.Type,
+		&i.Fingerprint,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const RevokeAPIKey = `-- name: RevokeAPIKey :execrows
+UPDATE api_keys
+SET revoked_at = $2
+WHERE id = $1 AND revoked_at IS NULL
+`
+
+type RevokeAPIKeyParams struct {
+	ID        string       `json:"id"`
+	RevokedAt sql.NullTime `json:"revoked_at"`
+}
+
+func (q *Queries) RevokeAPIKey(ctx context.Context, db DBTX, arg RevokeAPIKeyParams) (int64, error) {
+	result, err := db.ExecContext(ctx, RevokeAPIKey, arg.ID, arg.RevokedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const RevokeEphemeralKey = `-- name: RevokeEphemeralKey :execrows
+UPDATE ephemeral_keys
+SET revoked_at = $2
+WHERE id = $1 AND revoked_at IS NULL
+`
+
+type RevokeEphemeralKeyParams struct {
+	ID        string       `json:"id"`
+	RevokedAt sql.NullTime `json:"revoked_at"`
+}
+
+func (q *Queries) RevokeEphemeralKey(ctx context.Context, db DBTX, arg RevokeEphemeralKeyParams) (int64, error) {
+	result, err := db.ExecContext(ctx, RevokeEphemeralKey, arg.ID, arg.RevokedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const SaveDisputeEvidence = `-- name: SaveDisputeEvidence :one
+INSERT INTO dispute_evidence (
+    dispute_id, fields, updated_by
+) VALUES (
+    $1, $2, $3
+)
+ON CONFLICT (dispute_id) DO UPDATE
+SET fields = EXCLUDED.fields,
+    updated_by = EXCLUDED.updated_by
+RETURNING dispute_id, fields, updated_by, submitted_by, submitted_at, created_at, updated_at
+`
+
+type SaveDisputeEvidenceParams struct {
+	DisputeID string          `json:"dispute_id"`
+	Fields    json.RawMessage `json:"fields"`
+	UpdatedBy string          `json:"updated_by"`
+}
+
+func (q *Queries) SaveDisputeEvidence(ctx context.Context, db DBTX, arg SaveDisputeEvidenceParams) (DisputeEvidence, error) {
+	row := db.QueryRowContext(ctx, SaveDisputeEvidence, arg.DisputeID, arg.Fields, arg.UpdatedBy)
+	var i DisputeEvidence
+	err := row.Scan(
+		&i.DisputeID,
+		&i.Fields,
+		&i.UpdatedBy,
+		&i.SubmittedBy,
+		&i.SubmittedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const SaveSimulatorObject = `-- name: SaveSimulatorObject :exec
+INSERT INTO simulator_objects (
+    tenant_id, id, kind, parent_id, data
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (tenant_id, id) DO UPDATE
+SET parent_id = EXCLUDED.parent_id, data = EXCLUDED.data, updated_at = NOW()
+`
+
+type SaveSimulatorObjectParams struct {
+	TenantID string          `json:"tenant_id"`
+	ID       string          `json:"id"`
+	Kind     string          `json:"kind"`
+	ParentID string          `json:"parent_id"`
+	Data     json.RawMessage `json:"data"`
+}
+
+func (q *Queries) SaveSimulatorObject(ctx context.Context, db DBTX, arg SaveSimulatorObjectParams) error {
+	_, err := db.ExecContext(ctx, SaveSimulatorObject,
+		arg.TenantID,
+		arg.ID,
+		arg.Kind,
+		arg.ParentID,
+		arg.Data,
+	)
+	return err
+}
+
+const SearchTenantCharges = `-- name: SearchTenantCharges :many
+SELECT id, amount, currency, status, customer_id, payment_method_id, description, metadata, created_at, updated_at, amount_refunded, captured, refunded, disputed, invoice_id, synced_at, tenant_id FROM charges
+WHERE tenant_id = $1
+  AND ($2::text = '' OR status = $2::text)
+  AND ($3::text = '' OR currency = $3::text)
+  AND ($4::text = '' OR customer_id = $4::text)
+  AND ($5::bigint IS NULL OR amount >= $5)
+  AND ($6::bigint IS NULL OR amount <= $6)
+  AND ($7::text = '' AND $8::text = '' OR EXISTS (
+      SELECT 1 FROM payment_methods
+      WHERE payment_methods.id = charges.payment_method_id
+        AND ($7::text = '' OR lower(payment_methods.card_brand) = $7::text)
+        AND ($8::text = '' OR payment_methods.card_last4 = $8::text)
+  ))
+  AND ($9::jsonb = '{}'::jsonb OR metadata @> $9::jsonb)
+  AND ($10::timestamptz IS NULL OR created_at >= $10)
+  AND ($11::timestamptz IS NULL OR created_at < $11)
+  AND ($12::text = '' OR (created_at, id) < (
+      SELECT created_at, id FROM charges WHERE id = $12::text
+  ))
+ORDER BY created_at DESC, id DESC
+LIMIT $13
+`
+
+type SearchTenantChargesParams struct {
+	TenantID      string          `json:"tenant_id"`
+	Status        string          `json:"status"`
+	Currency      string          `json:"currency"`
+	CustomerID    string          `json:"customer_id"`
+	MinAmount     sql.NullInt64   `json:"min_amount"`
+	MaxAmount     sql.NullInt64   `json:"max_amount"`
+	CardBrand     string          `json:"card_brand"`
+	CardLast4     string          `json:"card_last4"`
+	Metadata      json.RawMessage `json:"metadata"`
+	CreatedAfter  sql.NullTime    `json:"created_after"`
+	CreatedBefore sql.NullTime    `json:"created_before"`
+	StartingAfter string          `json:"starting_after"`
+	Limit         int32           `json:"limit"`
+}
+
+func (q *Queries) SearchTenantCharges(ctx context.Context, db DBTX, arg SearchTenantChargesParams) ([]Charge, error) {
+	rows, err := db.QueryContext(ctx, SearchTenantCharges,
+		arg.TenantID,
+		arg.Status,
+		arg.Currency,
+		arg.CustomerID,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.CardBrand,
+		arg.CardLast4,
+		arg.Metadata,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.StartingAfter,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Charge{}
+	for rows.Next() {
+		var i Charge
+		if err := rows.Scan(
+			&i.ID,
+			&i.Amount,
+			&i.Currency,
+			&i.Status,
+			&i.CustomerID,
+			&i.PaymentMethodID,
+			&i.Description,
+			&i.Metadata,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.AmountRefunded,
+			&i.Captured,
+			&i.Refunded,
+			&i.Disputed,
+			&i.InvoiceID,
+			&i.SyncedAt,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const SearchTenantCustomers = `-- name: SearchTenantCustomers :many
+SELECT id, email, name, phone, description, metadata, created_at, updated_at, synced_at, tenant_id FROM customers
+WHERE tenant_id = $1
+  AND (id = $2::text
+    OR lower(email) LIKE $3::text || '%'
+    OR lower(name) LIKE $3::text || '%')
+  AND NOT EXISTS (SELECT 1 FROM customer_deletions WHERE customer_deletions.customer_id = customers.id)
+ORDER BY (id = $2::text) DESC, name, id
+LIMIT $4
+`
+
+type SearchTenantCustomersParams struct {
+	TenantID string `json:"tenant_id"`
+	Query    string `json:"query"`
+	Prefix   string `json:"prefix"`
+	Limit    int32  `json:"limit"`
+}
+
+func (q *Queries) SearchTenantCustomers(ctx context.Context, db DBTX, arg SearchTenantCustomersParams) ([]Customer, error) {
+	rows, err := db.QueryContext(ctx, SearchTenantCustomers,
+		arg.TenantID,
+		arg.Query,
+		arg.Prefix,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Customer{}
+	for rows.Next() {
+		var i Customer
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+			&i.Name,
+			&i.Phone,
+			&i.Description,
+			&i.Metadata,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.SyncedAt,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const SetBlocklistEntryProviderItem = `-- name: SetBlocklistEntryProviderItem :exec
+UPDATE blocklist_entries
+SET provider_item_id = $2
+WHERE id = $1
+`
+
+type SetBlocklistEntryProviderItemParams struct {
+	ID             string `json:"id"`
+	ProviderItemID string `json:"provider_item_id"`
+}
+
+func (q *Queries) SetBlocklistEntryProviderItem(ctx context.Context, db DBTX, arg SetBlocklistEntryProviderItemParams) error {
+	_, err := db.ExecContext(ctx, SetBlocklistEntryProviderItem, arg.ID, arg.ProviderItemID)
+	return err
+}
+
+const SetCustomerBalanceTransactionSource = `-- name: SetCustomerBalanceTransactionSource :exec
+UPDATE customer_balance_transactions
+SET source_id = $2
+WHERE id = $1
+`
+
+type SetCustomerBalanceTransactionSourceParams struct {
+	ID       string `json:"id"`
+	SourceID string `json:"source_id"`
+}
+
+func (q *Queries) SetCustomerBalanceTransactionSource(ctx context.Context, db DBTX, arg SetCustomerBalanceTransactionSourceParams) error {
+	_, err := db.ExecContext(ctx, SetCustomerBalanceTransactionSource, arg.ID, arg.SourceID)
+	return err
+}
+
+const SetCustomerVerificationToken = `-- name: SetCustomerVerificationToken :exec
+UPDATE customer_identities
+SET verification_token_hash = $2,
+    verification_expires_at = $3
+WHERE customer_id = $1
+`
+
+type SetCustomerVerificationTokenParams struct {
+	CustomerID            string       `json:"customer_id"`
+	VerificationTokenHash string       `json:"verification_token_hash"`
+	VerificationExpiresAt sql.NullTime `json:"verification_expires_at"`
+}
+
+func (q *Queries) SetCustomerVerificationToken(ctx context.Context, db DBTX, arg SetCustomerVerificationTokenParams) error {
+	_, err := db.ExecContext(ctx, SetCustomerVerificationToken, arg.CustomerID, arg.VerificationTokenHash, arg.VerificationExpiresAt)
+	return err
+}
+
+const StoreOffboardingArchive = `-- name: StoreOffboardingArchive :exec
+INSERT INTO offboarding_archives (
+    export_id, archive
+) VALUES (
+    $1, $2
+)
+ON CONFLICT (export_id) DO UPDATE SET archive = EXCLUDED.archive, created_at = NOW()
+`
+
+type StoreOffboardingArchiveParams struct {
+	ExportID string `json:"export_id"`
+	Archive  []byte `json:"archive"`
+}
+
+func (q *Queries) StoreOffboardingArchive(ctx context.Context, db DBTX, arg StoreOffboardingArchiveParams) error {
+	_, err := db.ExecContext(ctx, StoreOffboardingArchive, arg.ExportID, arg.Archive)
+	return err
+}
+
+const SumCustomerBalanceTransactionsBySource = `-- name: SumCustomerBalanceTransactionsBySource :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS total FROM customer_balance_transactions
+WHERE tenant_id = $1 AND type = $2 AND source_id = $3
+`
+
+type SumCustomerBalanceTransactionsBySourceParams struct {
+	TenantID string `json:"tenant_id"`
+	Type     string `json:"type"`
+	SourceID string `json:"source_id"`
+}
+
+func (q *Queries) SumCustomerBalanceTransactionsBySource(ctx context.Context, db DBTX, arg SumCustomerBalanceTransactionsBySourceParams) (int64, error) {
+	row := db.QueryRowContext(ctx, SumCustomerBalanceTransactionsBySource, arg.TenantID, arg.Type, arg.SourceID)
+	var total int64
+	err := row.Scan(&total)
+	return total, err
+}
+
+const SummarizeRoutedCharges = `-- name: SummarizeRoutedCharges :many
+SELECT provider, currency, settlement_currency, COUNT(*) AS charge_count, COALESCE(SUM(amount), 0)::bigint AS total_amount
+FROM routed_charges
+WHERE created_at >= $1 AND created_at < $2
+GROUP BY provider, currency, settlement_currency
+ORDER BY provider, currency
+`
+
+type SummarizeRoutedChargesParams struct {
+	CreatedFrom sql.NullTime `json:"created_from"`
+	CreatedTo   sql.NullTime `json:"created_to"`
+}
+
+type SummarizeRoutedChargesRow struct {
+	Provider           string `json:"provider"`
+	Currency           string `json:"currency"`
+	SettlementCurrency string `json:"settlement_currency"`
+	ChargeCount        int64  `json:"charge_count"`
+	TotalAmount        int64  `json:"total_amount"`
+}
+
+func (q *Queries) SummarizeRoutedCharges(ctx context.Context, db DBTX, arg SummarizeRoutedChargesParams) ([]SummarizeRoutedChargesRow, error) {
+	rows, err := db.QueryContext(ctx, SummarizeRoutedCharges, arg.CreatedFrom, arg.CreatedTo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SummarizeRoutedChargesRow{}
+	for rows.Next() {
+		var i SummarizeRoutedChargesRow
+		if err := rows.Scan(
+			&i.Provider,
+			&i.Currency,
+			&i.SettlementCurrency,
+			&i.ChargeCount,
+			&i.TotalAmount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const TrackAuthorization = `-- name: TrackAuthorization :execrows
+INSERT INTO authorizations (
+    charge_id, tenant_id, provider, amount, currency, multi_capture, status, expires_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+ON CONFLICT DO NOTHING
+`
+
+type TrackAuthorizationParams struct {
+	ChargeID     string    `json:"charge_id"`
+	TenantID     string    `json:"tenant_id"`
+	Provider     string    `json:"provider"`
+	Amount       int64     `json:"amount"`
+	Currency     string    `json:"currency"`
+	MultiCapture bool      `json:"multi_capture"`
+	Status       string    `json:"status"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+func (q *Queries) TrackAuthorization(ctx context.Context, db DBTX, arg TrackAuthorizationParams) (int64, error) {
+	result, err := db.ExecContext(ctx, TrackAuthorization,
+		arg.ChargeID,
+		arg.TenantID,
+		arg.Provider,
+		arg.Amount,
+		arg.Currency,
+		arg.MultiCapture,
+		arg.Status,
+		arg.ExpiresAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const UpdateAuthorization = `-- name: UpdateAuthorization :one
+UPDATE authorizations
+SET amount_captured = $2, captures = $3, status = $4, closed_at = $5
+WHERE charge_id = $1 AND status = 'open'
+RETURNING charge_id, tenant_id, provider, amount, amount_captured, currency, captures, multi_capture, status, expires_at, closed_at, created_at, updated_at
+`
+
+type UpdateAuthorizationParams struct {
+	ChargeID       string       `json:"charge_id"`
+	AmountCaptured int64        `json:"amount_captured"`
+	Captures       int32        `json:"captures"`
+	Status         string       `json:"status"`
+	ClosedAt       sql.NullTime `json:"closed_at"`
+}
+
+func (q *Queries) UpdateAuthorization(ctx context.Context, db DBTX, arg UpdateAuthorizationParams) (Authorization, error) {
+	row := db.QueryRowContext(ctx, UpdateAuthorization,
+		arg.ChargeID,
+		arg.AmountCaptured,
+		arg.Captures,
+		arg.Status,
+		arg.ClosedAt,
+	)
+	var i Authorization
+	err := row.Scan(
+		&i.ChargeID,
+		&i.TenantID,
+		&i.Provider,
+		&i.Amount,
+		&i.AmountCaptured,
+		&i.Currency,
+		&i.Captures,
+		&i.MultiCapture,
+		&i.Status,
+		&i.ExpiresAt,
+		&i.ClosedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const UpdateChargeListRowRefund = `-- name: UpdateChargeListRowRefund :exec
+UPDATE charge_list_rows
+SET last_refund_id = $2,
+    last_refund_status = $3
+WHERE charge_id = $1
+`
+
+type UpdateChargeListRowRefundParams struct {
+	ChargeID         string `json:"charge_id"`
+	LastRefundID     string `json:"last_refund_id"`
+	LastRefundStatus string `json:"last_refund_status"`
+}
+
+func (q *Queries) UpdateChargeListRowRefund(ctx context.Context, db DBTX, arg UpdateChargeListRowRefundParams) error {
+	_, err := db.ExecContext(ctx, UpdateChargeListRowRefund, arg.ChargeID, arg.LastRefundID, arg.LastRefundStatus)
+	return err
+}
+
+const UpdateChargeListRowsCustomer = `-- name: UpdateChargeListRowsCustomer :exec
+UPDATE charge_list_rows
+SET customer_email = $2,
+    customer_name = $3
+WHERE customer_id = $1
+  AND NOT EXISTS (SELECT 1 FROM customer_erasures WHERE customer_erasures.customer_id = charge_list_rows.customer_id)
+`
+
+type UpdateChargeListRowsCustomerParams struct {
+	CustomerID    string `json:"customer_id"`
+	CustomerEmail string `json:"customer_email"`
+	CustomerName  string `json:"customer_name"`
+}
+
+func (q *Queries) UpdateChargeListRowsCustomer(ctx context.Context, db DBTX, arg UpdateChargeListRowsCustomerParams) error {
+	_, err := db.ExecContext(ctx, UpdateChargeListRowsCustomer, arg.CustomerID, arg.CustomerEmail, arg.CustomerName)
+	return err
+}
+
+const UpdateChargeStatus = `-- name: UpdateChargeStatus :one
+UPDATE charges
+SET status = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING id, amount, currency, status, customer_id, payment_method_id, description, metadata, created_at, updated_at, amount_refunded, captured, refunded, disputed, invoice_id, synced_at, tenant_id
+`
+
+type UpdateChargeStatusParams struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+func (q *Queries) UpdateChargeStatus(ctx context.Context, db DBTX, arg UpdateChargeStatusParams) (Charge, error) {
+	row := db.QueryRowContext(ctx, UpdateChargeStatus, arg.ID, arg.Status)
+	var i Charge
+	err := row.Scan(
+		&i.ID,
+		&i.Amount,
+		&i.Currency,
+		&i.Status,
+		&i.CustomerID,
+		&i.PaymentMethodID,
+		&i.Description,
+		&i.Metadata,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.AmountRefunded,
+		&i.Captured,
+		&i.Refunded,
+		&i.Disputed,
+		&i.InvoiceID,
+		&i.SyncedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const UpdateCompositeRefundLeg = `-- name: UpdateCompositeRefundLeg :one
+UPDATE composite_refund_legs
+SET refund_id = $3, approval_id = $4, status = $5, failure_reason = $6
+WHERE composite_refund_id = $1 AND charge_id = $2
+RETURNING composite_refund_id, charge_id, position, amount, refund_id, approval_id, status, failure_reason, created_at, updated_at
+`
+
+type UpdateCompositeRefundLegParams struct {
+	CompositeRefundID string `json:"composite_refund_id"`
+	ChargeID          string `json:"charge_id"`
+	RefundID          string `json:"refund_id"`
+	ApprovalID        string `json:"approval_id"`
+	Status            string `json:"status"`
+	FailureReason     string `json:"failure_reason"`
+}
+
+func (q *Queries) UpdateCompositeRefundLeg(ctx context.Context, db DBTX, arg UpdateCompositeRefundLegParams) (CompositeRefundLeg, error) {
+	row := db.QueryRowContext(ctx, UpdateCompositeRefundLeg,
+		arg.CompositeRefundID,
+		arg.ChargeID,
+		arg.RefundID,
+		arg.ApprovalID,
+		arg.Status,
+		arg.FailureReason,
+	)
+	var i CompositeRefundLeg
+	err := row.Scan(
+		&i.CompositeRefundID,
+		&i.ChargeID,
+		&i.Position,
+		&i.Amount,
+		&i.RefundID,
+		&i.ApprovalID,
+		&i.Status,
+		&i.FailureReason,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const UpdateCompositeRefundStatus = `-- name: UpdateCompositeRefundStatus :exec
+UPDATE composite_refunds
+SET status = $2
+WHERE id = $1
+`
+
+type UpdateCompositeRefundStatusParams struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+func (q *Queries) UpdateCompositeRefundStatus(ctx context.Context, db DBTX, arg UpdateCompositeRefundStatusParams) error {
+	_, err := db.ExecContext(ctx, UpdateCompositeRefundStatus, arg.ID, arg.Status)
+	return err
+}
+
+const UpdateCustomer = `-- name: UpdateCustomer :one
+UPDATE customers
+SET email = $2, name = $3, phone = $4, description = $5, metadata = $6, updated_at = NOW()
+WHERE id = $1
+RETURNING id, email, name, phone, description, metadata, created_at, updated_at, synced_at, tenant_id
+`
+
+type UpdateCustomerParams struct {
+	ID          string                `json:"id"`
+	Email       string                `json:"email"`
+	Name        string                `json:"name"`
+	Phone       sql.NullString        `json:"phone"`
+	Description sql.NullString        `json:"description"`
+	Metadata    pqtype.NullRawMessage `json:"metadata"`
+}
+
+func (q *Queries) UpdateCustomer(ctx context.Context, db DBTX, arg UpdateCustomerParams) (Customer, error) {
+	row := db.QueryRowContext(ctx, UpdateCustomer,
+		arg.ID,
+		arg.Email,
+		arg.Name,
+		arg.Phone,
+		arg.Description,
+		arg.Metadata,
+	)
+	var i Customer
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.Name,
+		&i.Phone,
+		&i.Description,
+		&i.Metadata,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SyncedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const UpdateCustomerIdentity = `-- name: UpdateCustomerIdentity :one
+UPDATE customer_identities
+SET normalized_email = $2,
+    name = $3,
+    email_verified_at = $4
+WHERE customer_id = $1
+RETURNING customer_id, tenant_id, normalized_email, name, email_verified_at, verification_token_hash, verification_expires_at, created_at, updated_at
+`
+
+type UpdateCustomerIdentityParams struct {
+	CustomerID      string       `json:"customer_id"`
+	NormalizedEmail string       `json:"normalized_email"`
+	Name            string       `json:"name"`
+	EmailVerifiedAt sql.NullTime `json:"email_verified_at"`
+}
+
+func (q *Queries) UpdateCustomerIdentity(ctx context.Context, db DBTX, arg UpdateCustomerIdentityParams) (CustomerIdentity, error) {
+	row := db.QueryRowContext(ctx, UpdateCustomerIdentity,
+		arg.CustomerID,
+		arg.NormalizedEmail,
+		arg.Name,
+		arg.EmailVerifiedAt,
+	)
+	var i CustomerIdentity
+	err := row.Scan(
+		&i.CustomerID,
+		&i.TenantID,
+		&i.NormalizedEmail,
+		&i.Name,
+		&i.EmailVerifiedAt,
+		&i.VerificationTokenHash,
+		&i.VerificationExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const UpdateDeadLetter = `-- name: UpdateDeadLetter :one
+UPDATE dlq_events
+SET status = $2, attempts = $3, last_error = $4, next_attempt_at = $5, resolved_at = $6
+WHERE id = $1
+RETURNING id, source, dedupe_key, payload, headers, status, attempts, last_error, next_attempt_at, resolved_at, created_at, updated_at
+`
+
+type UpdateDeadLetterParams struct {
+	ID            string       `json:"id"`
+	Status        string       `json:"status"`
+	Attempts      int32        `json:"attempts"`
+	LastError     string       `json:"last_error"`
+	NextAttemptAt sql.NullTime `json:"next_attempt_at"`
+	ResolvedAt    sql.NullTime `json:"resolved_at"`
+}
+
+func (q *Queries) UpdateDeadLetter(ctx context.Context, db DBTX, arg UpdateDeadLetterParams) (DlqEvent, error) {
+	row := db.QueryRowContext(ctx, UpdateDeadLetter,
+		arg.ID,
+		arg.Status,
+		arg.Attempts,
+		arg.LastError,
+		arg.NextAttemptAt,
+		arg.ResolvedAt,
+	)
+	var i DlqEvent
+	err := row.Scan(
+		&i.ID,
+		&i.Source,
+		&i.DedupeKey,
+		&i.Payload,
+		&i.Headers,
+		&i.Status,
+		&i.Attempts,
+		&i.LastError,
+		&i.NextAttemptAt,
+		&i.ResolvedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const UpdateDunningCase = `-- name: UpdateDunningCase :one
+UPDATE dunning_cases
+SET state = $2, attempts = $3, next_retry_at = $4, last_error = $5, resolved_at = $6
+WHERE id = $1 AND resolved_at IS NULL
+RETURNING id, invoice_id, subscription_id, customer_id, amount_due, currency, state, attempts, next_retry_at, last_error, failed_at, resolved_at, created_at, updated_at, tenant_id
+`
+
+type UpdateDunningCaseParams struct {
+	ID          string       `json:"id"`
+	State       string       `json:"state"`
+	Attempts    int32        `json:"attempts"`
+	NextRetryAt sql.NullTime `json:"next_retry_at"`
+	LastError   string       `json:"last_error"`
+	ResolvedAt  sql.NullTime `json:"resolved_at"`
+}
+
+func (q *Queries) UpdateDunningCase(ctx context.Context, db DBTX, arg UpdateDunningCaseParams) (DunningCase, error) {
+	row := db.QueryRowContext(ctx, UpdateDunningCase,
+		arg.ID,
+		arg.State,
+		arg.Attempts,
+		arg.NextRetryAt,
+		arg.LastError,
+		arg.ResolvedAt,
+	)
+	var i DunningCase
+	err := row.Scan(
+		&i.ID,
+		&i.InvoiceID,
+		&i.SubscriptionID,
+		&i.CustomerID,
+		&i.AmountDue,
+		&i.Currency,
+		&i.State,
+		&i.Attempts,
+		&i.NextRetryAt,
+		&i.LastError,
+		&i.FailedAt,
+		&i.ResolvedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const UpdateRefundStatus = `-- name: UpdateRefundStatus :one
+UPDATE refunds
+SET status = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING id, charge_id, amount, currency, status, reason, metadata, created_at, updated_at, synced_at, tenant_id
+`
+
+type UpdateRefundStatusParams struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+func (q *Queries) UpdateRefundStatus(ctx context.Context, db DBTX, arg UpdateRefundStatusParams) (Refund, error) {
+	row := db.QueryRowContext(ctx, UpdateRefundStatus, arg.ID, arg.Status)
+	var i Refund
+	err := row.Scan(
+		&i.ID,
+		&i.ChargeID,
+		&i.Amount,
+		&i.Currency,
+		&i.Status,
+		&i.Reason,
+		&i.Metadata,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SyncedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const UpdateSmartRoutingRule = `-- name: UpdateSmartRoutingRule :one
+UPDATE smart_routing_rules
+SET currency = $2,
+    bin_country = $3,
+    min_amount = $4,
+    max_amount = $5,
+    strategy = $6,
+    providers = $7,
+    pinned_provider = $8,
+    priority = $9,
+    enabled = $10,
+    description = $11,
+    updated_by = $12
+WHERE id = $1
+RETURNING id, currency, bin_country, min_amount, max_amount, strategy, providers, pinned_provider, priority, enabled, description, updated_by, created_at, updated_at
+`
+
+type UpdateSmartRoutingRuleParams struct {
+	ID             string          `json:"id"`
+	Currency       string          `json:"currency"`
+	BinCountry     string          `json:"bin_country"`
+	MinAmount      int64           `json:"min_amount"`
+	MaxAmount      int64           `json:"max_amount"`
+	Strategy       string          `json:"strategy"`
+	Providers      json.RawMessage `json:"providers"`
+	PinnedProvider string          `json:"pinned_provider"`
+	Priority       int32           `json:"priority"`
+	Enabled        bool            `json:"enabled"`
+	Description    string          `json:"description"`
+	UpdatedBy      string          `json:"updated_by"`
+}
+
+func (q *Queries) UpdateSmartRoutingRule(ctx context.Context, db DBTX, arg UpdateSmartRoutingRuleParams) (SmartRoutingRule, error) {
+	row := db.QueryRowContext(ctx, UpdateSmartRoutingRule,
+		arg.ID,
+		arg.Currency,
+		arg.BinCountry,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.Strategy,
+		arg.Providers,
+		arg.PinnedProvider,
+		arg.Priority,
+		arg.Enabled,
+		arg.Description,
+		arg.UpdatedBy,
+	)
+	var i SmartRoutingRule
+	err := row.Scan(
+		&i.ID,
+		&i.Currency,
+		&i.BinCountry,
+		&i.MinAmount,
+		&i.MaxAmount,
+		&i.Strategy,
+		&i.Providers,
+		&i.PinnedProvider,
+		&i.Priority,
+		&i.Enabled,
+		&i.Description,
+		&i.UpdatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const UpdateVaultTokenFingerprint = `-- name: UpdateVaultTokenFingerprint :exec
+UPDATE vault_tokens
+SET fingerprint = $3
+WHERE provider = $1 AND provider_token = $2
+`
+
+type UpdateVaultTokenFingerprintParams struct {
+	Provider      string `json:"provider"`
+	ProviderToken string `json:"provider_token"`
+	Fingerprint   string `json:"fingerprint"`
+}
+
+func (q *Queries) UpdateVaultTokenFingerprint(ctx context.Context, db DBTX, arg UpdateVaultTokenFingerprintParams) error {
+	_, err := db.ExecContext(ctx, UpdateVaultTokenFingerprint, arg.Provider, arg.ProviderToken, arg.Fingerprint)
+	return err
+}
+
+const UpdateWebhookDelivery = `-- name: UpdateWebhookDelivery :one
+UPDATE webhook_deliveries
+SET status = $2, attempts = $3, last_status_code = $4, last_error = $5, next_attempt_at = $6, delivered_at = $7
+WHERE id = $1
+RETURNING id, tenant_id, endpoint_id, event_id, event_type, payload, status, attempts, last_status_code, last_error, next_attempt_at, delivered_at, created_at, updated_at
+`
+
+type UpdateWebhookDeliveryParams struct {
+	ID             string       `json:"id"`
+	Status         string       `json:"status"`
+	Attempts       int32        `json:"attempts"`
+	LastStatusCode int32        `json:"last_status_code"`
+	LastError      string       `json:"last_error"`
+	NextAttemptAt  sql.NullTime `json:"next_attempt_at"`
+	DeliveredAt    sql.NullTime `json:"delivered_at"`
+}
+
+func (q *Queries) UpdateWebhookDelivery(ctx context.Context, db DBTX, arg UpdateWebhookDeliveryParams) (WebhookDelivery, error) {
+	row := db.QueryRowContext(ctx, UpdateWebhookDelivery,
+		arg.ID,
+		arg.Status,
+		arg.Attempts,
+		arg.LastStatusCode,
+		arg.LastError,
+		arg.NextAttemptAt,
+		arg.DeliveredAt,
+	)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.EndpointID,
+		&i.EventID,
+		&i.EventType,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.LastStatusCode,
+		&i.LastError,
+		&i.NextAttemptAt,
+		&i.DeliveredAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const UpdateWebhookEndpoint = `-- name: UpdateWebhookEndpoint :one
+UPDATE webhook_endpoints
+SET url = $3, description = $4, event_types = $5, enabled = $6
+WHERE tenant_id = $1 AND id = $2
+RETURNING id, tenant_id, url, description, event_types, secret_ciphertext, enabled, created_at, updated_at
+`
+
+type UpdateWebhookEndpointParams struct {
+	TenantID    string          `json:"tenant_id"`
+	ID          string          `json:"id"`
+	Url         string          `json:"url"`
+	Description string          `json:"description"`
+	EventTypes  json.RawMessage `json:"event_types"`
+	Enabled     bool            `json:"enabled"`
+}
+
+func (q *Queries) UpdateWebhookEndpoint(ctx context.Context, db DBTX, arg UpdateWebhookEndpointParams) (WebhookEndpoint, error) {
+	row := db.QueryRowContext(ctx, UpdateWebhookEndpoint,
+		arg.TenantID,
+		arg.ID,
+		arg.Url,
+		arg.Description,
+		arg.EventTypes,
+		arg.Enabled,
+	)
+	var i WebhookEndpoint
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.Url,
+		&i.Description,
+		&i.EventTypes,
+		&i.SecretCiphertext,
+		&i.Enabled,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const UpsertAutoRefundExclusion = `-- name: UpsertAutoRefundExclusion :one
+INSERT INTO auto_refund_exclusions (
+    customer_id, reason
+) VALUES (
+    $1, $2
+)
+ON CONFLICT (customer_id) DO UPDATE SET
+    reason = EXCLUDED.reason
+RETURNING customer_id, reason, created_at
+`
+
+type UpsertAutoRefundExclusionParams struct {
+	CustomerID string `json:"customer_id"`
+	Reason     string `json:"reason"`
+}
+
+func (q *Queries) UpsertAutoRefundExclusion(ctx context.Context, db DBTX, arg UpsertAutoRefundExclusionParams) (AutoRefundExclusion, error) {
+	row := db.QueryRowContext(ctx, UpsertAutoRefundExclusion, arg.CustomerID, arg.Reason)
+	var i AutoRefundExclusion
+	err := row.Scan(&i.CustomerID, &i.Reason, &i.CreatedAt)
+	return i, err
+}
+
+const UpsertChargeListRow = `-- name: UpsertChargeListRow :exec
+INSERT INTO charge_list_rows (
+    charge_id, tenant_id, customer_id, customer_email, customer_name, plan_name,
+    amount, amount_refunded, currency, status, description, charge_created
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+)
+ON CONFLICT (charge_id) DO UPDATE
+SET tenant_id = EXCLUDED.tenant_id,
+    customer_id = EXCLUDED.customer_id,
+    customer_email = EXCLUDED.customer_email,
+    customer_name = EXCLUDED.customer_name,
+    plan_name = EXCLUDED.plan_name,
+    amount = EXCLUDED.amount,
+    amount_refunded = EXCLUDED.amount_refunded,
+    currency = EXCLUDED.currency,
+    status = EXCLUDED.status,
+    description = EXCLUDED.description
+`
+
+type UpsertChargeListRowParams struct {
+	ChargeID       string    `json:"charge_id"`
+	TenantID       string    `json:"tenant_id"`
+	CustomerID     string    `json:"customer_id"`
+	CustomerEmail  string    `json:"customer_email"`
+	CustomerName   string    `json:"customer_name"`
+	PlanName       string    `json:"plan_name"`
+	Amount         int64     `json:"amount"`
+	AmountRefunded int64     `json:"amount_refunded"`
+	Currency       string    `json:"currency"`
+	Status         string    `json:"status"`
+	Description    string    `json:"description"`
+	ChargeCreated  time.Time `json:"charge_created"`
+}
+
+func (q *Queries) UpsertChargeListRow(ctx context.Context, db DBTX, arg UpsertChargeListRowParams) error {
+	_, err := db.ExecContext(ctx, UpsertChargeListRow,
+		arg.ChargeID,
+		arg.TenantID,
+		arg.CustomerID,
+		arg.CustomerEmail,
+		arg.CustomerName,
+		arg.PlanName,
+		arg.Amount,
+		arg.AmountRefunded,
+		arg.Currency,
+		arg.Status,
+		arg.Description,
+		arg.ChargeCreated,
+	)
+	return err
+}
+
+const UpsertCustomFieldDefinition = `-- name: UpsertCustomFieldDefinition :one
+INSERT INTO custom_field_definitions (
+    tenant_id, fields
+) VALUES (
+    $1, $2
+)
+ON CONFLICT (tenant_id) DO UPDATE
+SET fields = EXCLUDED.fields
+RETURNING tenant_id, fields, created_at, updated_at
+`
+
+type UpsertCustomFieldDefinitionParams struct {
+	TenantID string          `json:"tenant_id"`
+	Fields   json.RawMessage `json:"fields"`
+}
+
+func (q *Queries) UpsertCustomFieldDefinition(ctx context.Context, db DBTX, arg UpsertCustomFieldDefinitionParams) (CustomFieldDefinition, error) {
+	row := db.QueryRowContext(ctx, UpsertCustomFieldDefinition, arg.TenantID, arg.Fields)
+	var i CustomFieldDefinition
+	err := row.Scan(
+		&i.TenantID,
+		&i.Fields,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const UpsertDispute = `-- name: UpsertDispute :exec
+INSERT INTO disputes (
+    id, charge_id, payment_intent_id, amount, currency, reason, status,
+    network_reason_code, is_charge_refundable, evidence, evidence_due_by,
+    has_evidence, past_due, submission_count, payment_method_type, card_brand,
+    card_network_reason_code, balance_transactions, livemode, metadata,
+    disputed_at, synced_at, tenant_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22,
+    COALESCE(NULLIF($23, ''), (SELECT tenant_id FROM charges WHERE charges.id = $2), 'default')
+)
+ON CONFLICT (id) DO UPDATE
+SET charge_id = EXCLUDED.charge_id,
+    payment_intent_id = EXCLUDED.payment_intent_id,
+    amount = EXCLUDED.amount,
+    currency = EXCLUDED.currency,
+    reason = EXCLUDED.reason,
+    status = EXCLUDED.status,
+    network_reason_code = EXCLUDED.network_reason_code,
+    is_charge_refundable = EXCLUDED.is_charge_refundable,
+    evidence = EXCLUDED.evidence,
+    evidence_due_by = EXCLUDED.evidence_due_by,
+    has_evidence = EXCLUDED.has_evidence,
+    past_due = EXCLUDED.past_due,
+    submission_count = EXCLUDED.submission_count,
+    payment_method_type = EXCLUDED.payment_method_type,
+    card_brand = EXCLUDED.card_brand,
+    card_network_reason_code = EXCLUDED.card_network_reason_code,
+    balance_transactions = EXCLUDED.balance_transactions,
+    livemode = EXCLUDED.livemode,
+    metadata = EXCLUDED.metadata,
+    synced_at = EXCLUDED.synced_at
+WHERE disputes.synced_at <= EXCLUDED.synced_at
+`
+
+type UpsertDisputeParams struct {
+	ID                    string          `json:"id"`
+	ChargeID              string          `json:"charge_id"`
+	PaymentIntentID       string          `json:"payment_intent_id"`
+	Amount                int64           `json:"amount"`
+	Currency              string          `json:"currency"`
+	Reason                string          `json:"reason"`
+	Status                string          `json:"status"`
+	NetworkReasonCode     string          `json:"network_reason_code"`
+	IsChargeRefundable    bool            `json:"is_charge_refundable"`
+	Evidence              json.RawMessage `json:"evidence"`
+	EvidenceDueBy         sql.NullTime    `json:"evidence_due_by"`
+	HasEvidence           bool            `json:"has_evidence"`
+	PastDue               bool            `json:"past_due"`
+	SubmissionCount       int32           `json:"submission_count"`
+	PaymentMethodType     string          `json:"payment_method_type"`
+	CardBrand             string          `json:"card_brand"`
+	CardNetworkReasonCode string          `json:"card_network_reason_code"`
+	BalanceTransactions   json.RawMessage `json:"balance_transactions"`
+	Livemode              bool            `json:"livemode"`
+	Metadata              json.RawMessage `json:"metadata"`
+	DisputedAt            time.Time       `json:"disputed_at"`
+	SyncedAt              time.Time       `json:"synced_at"`
+	TenantID              string          `json:"tenant_id"`
+}
+
+func (q *Queries) UpsertDispute(ctx context.Context, db DBTX, arg UpsertDisputeParams) error {
+	_, err := db.ExecContext(ctx, UpsertDispute,
+		arg.ID,
+		arg.ChargeID,
+		arg.PaymentIntentID,
+		arg.Amount,
+		arg.Currency,
+		arg.Reason,
+		arg.Status,
+		arg.NetworkReasonCode,
+		arg.IsChargeRefundable,
+		arg.Evidence,
+		arg.EvidenceDueBy,
+		arg.HasEvidence,
+		arg.PastDue,
+		arg.SubmissionCount,
+		arg.PaymentMethodType,
+		arg.CardBrand,
+		arg.CardNetworkReasonCode,
+		arg.BalanceTransactions,
+		arg.Livemode,
+		arg.Metadata,
+		arg.DisputedAt,
+		arg.SyncedAt,
+		arg.TenantID,
+	)
+	return err
+}
+
+const UpsertDocument = `-- name: UpsertDocument :one
+INSERT INTO documents (
+    id, tenant_id, kind, source_id, language, object_key, checksum, size_bytes
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+ON CONFLICT (tenant_id, kind, source_id, language) DO UPDATE
+SET object_key = EXCLUDED.object_key,
+    checksum = EXCLUDED.checksum,
+    size_bytes = EXCLUDED.size_bytes
+RETURNING id, tenant_id, kind, source_id, language, object_key, checksum, size_bytes, created_at, updated_at
+`
+
+type UpsertDocumentParams struct {
+	ID        string `json:"id"`
+	TenantID  string `json:"tenant_id"`
+	Kind      string `json:"kind"`
+	SourceID  string `json:"source_id"`
+	Language  string `json:"language"`
+	ObjectKey string `json:"object_key"`
+	Checksum  string `json:"checksum"`
+	SizeBytes int64  `json:"size_bytes"`
+}
+
+func (q *Queries) UpsertDocument(ctx context.Context, db DBTX, arg UpsertDocumentParams) (Document, error) {
+	row := db.QueryRowContext(ctx, UpsertDocument,
+		arg.ID,
+		arg.TenantID,
+		arg.Kind,
+		arg.SourceID,
+		arg.Language,
+		arg.ObjectKey,
+		arg.Checksum,
+		arg.SizeBytes,
+	)
+	var i Document
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.Kind,
+		&i.SourceID,
+		&i.Language,
+		&i.ObjectKey,
+		&i.Checksum,
+		&i.SizeBytes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const UpsertDocumentTemplate = `-- name: UpsertDocumentTemplate :one
+INSERT INTO document_templates (
+    tenant_id, kind, business_name, address, tax_id, accent_color, footer, language
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+ON CONFLICT (tenant_id, kind) DO UPDATE
+SET business_name = EXCLUDED.business_name,
+    address = EXCLUDED.address,
+    tax_id = EXCLUDED.tax_id,
+    accent_color = EXCLUDED.accent_color,
+    footer = EXCLUDED.footer,
+    language = EXCLUDED.language
+RETURNING tenant_id, kind, business_name, address, tax_id, accent_color, footer, language, created_at, updated_at
+`
+
+type UpsertDocumentTemplateParams struct {
+	TenantID     string `json:"tenant_id"`
+	Kind         string `json:"kind"`
+	BusinessName string `json:"business_name"`
+	Address      string `json:"address"`
+	TaxID        string `json:"tax_id"`
+	AccentColor  string `json:"accent_color"`
+	Footer       string `json:"footer"`
+	Language     string `json:"language"`
+}
+
+func (q *Queries) UpsertDocumentTemplate(ctx context.Context, db DBTX, arg UpsertDocumentTemplateParams) (DocumentTemplate, error) {
+	row := db.QueryRowContext(ctx, UpsertDocumentTemplate,
+		arg.TenantID,
+		arg.Kind,
+		arg.BusinessName,
+		arg.Address,
+		arg.TaxID,
+		arg.AccentColor,
+		arg.Footer,
+		arg.Language,
+	)
+	var i DocumentTemplate
+	err := row.Scan(
+		&i.TenantID,
+		&i.Kind,
+		&i.BusinessName,
+		&i.Address,
+		&i.TaxID,
+		&i.AccentColor,
+		&i.Footer,
+		&i.Language,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const UpsertHoldPolicy = `-- name: UpsertHoldPolicy :one
+INSERT INTO hold_policies (
+    tenant_id, pause_on_dispute, pause_on_fraud, block_charges_on_dispute, block_charges_on_fraud, min_risk_score, auto_release
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+ON CONFLICT (tenant_id) DO UPDATE
+SET pause_on_dispute = EXCLUDED.pause_on_dispute,
+    pause_on_fraud = EXCLUDED.pause_on_fraud,
+    block_charges_on_dispute = EXCLUDED.block_charges_on_dispute,
+    block_charges_on_fraud = EXCLUDED.block_charges_on_fraud,
+    min_risk_score = EXCLUDED.min_risk_score,
+    auto_release = EXCLUDED.auto_release,
+    updated_at = NOW()
+RETURNING tenant_id, pause_on_dispute, pause_on_fraud, block_charges_on_dispute, block_charges_on_fraud, min_risk_score, auto_release, created_at, updated_at
+`
+
+type UpsertHoldPolicyParams struct {
+	TenantID              string `json:"tenant_id"`
+	PauseOnDispute        bool   `json:"pause_on_dispute"`
+	PauseOnFraud          bool   `json:"pause_on_fraud"`
+	BlockChargesOnDispute bool   `json:"block_charges_on_dispute"`
+	BlockChargesOnFraud   bool   `json:"block_charges_on_fraud"`
+	MinRiskScore          int32  `json:"min_risk_score"`
+	AutoRelease           bool   `json:"auto_release"`
+}
+
+func (q *Queries) UpsertHoldPolicy(ctx context.Context, db DBTX, arg UpsertHoldPolicyParams) (HoldPolicy, error) {
+	row := db.QueryRowContext(ctx, UpsertHoldPolicy,
+		arg.TenantID,
+		arg.PauseOnDispute,
+		arg.PauseOnFraud,
+		arg.BlockChargesOnDispute,
+		arg.BlockChargesOnFraud,
+		arg.MinRiskScore,
+		arg.AutoRelease,
+	)
+	var i HoldPolicy
+	err := row.Scan(
+		&i.TenantID,
+		&i.PauseOnDispute,
+		&i.PauseOnFraud,
+		&i.BlockChargesOnDispute,
+		&i.BlockChargesOnFraud,
+		&i.MinRiskScore,
+		&i.AutoRelease,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const UpsertInvoice = `-- name: UpsertInvoice :exec
+INSERT INTO invoices (
+    id, customer_id, subscription_id, number, status, collection_method,
+    description, amount_due, amount_paid, amount_remaining, currency, due_date,
+    paid_out_of_band, hosted_invoice_url, invoice_pdf, custom_fields, metadata,
+    invoiced_at, synced_at, customer_name, customer_email, subtotal, tax, total,
+    lines, tax_amounts, tenant_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19,
+    $20, $21, $22, $23, $24, $25, $26,
+    COALESCE(NULLIF($27, ''), (SELECT tenant_id FROM customers WHERE customers.id = $2), 'default')
+)
+ON CONFLICT (id) DO UPDATE
+SET customer_id = EXCLUDED.customer_id,
+    subscription_id = EXCLUDED.subscription_id,
+    number = EXCLUDED.number,
+    status = EXCLUDED.status,
+    collection_method = EXCLUDED.collection_method,
+    description = EXCLUDED.description,
+    amount_due = EXCLUDED.amount_due,
+    amount_paid = EXCLUDED.amount_paid,
+    amount_remaining = EXCLUDED.amount_remaining,
+    currency = EXCLUDED.currency,
+    due_date = EXCLUDED.due_date,
+    paid_out_of_band = EXCLUDED.paid_out_of_band,
+    hosted_invoice_url = EXCLUDED.hosted_invoice_url,
+    invoice_pdf = EXCLUDED.invoice_pdf,
+    custom_fields = EXCLUDED.custom_fields,
+    metadata = EXCLUDED.metadata,
+    synced_at = EXCLUDED.synced_at,
+    customer_name = EXCLUDED.customer_name,
+    customer_email = EXCLUDED.customer_email,
+    subtotal = EXCLUDED.subtotal,
+    tax = EXCLUDED.tax,
+    total = EXCLUDED.total,
+    lines = EXCLUDED.lines,
+    tax_amounts = EXCLUDED.tax_amounts
+WHERE invoices.synced_at <= EXCLUDED.synced_at
+`
+
+type UpsertInvoiceParams struct {
+	ID               string          `json:"id"`
+	CustomerID       string          `json:"customer_id"`
+	SubscriptionID   string          `json:"subscription_id"`
+	Number           string          `json:"number"`
+	Status           string          `json:"status"`
+	CollectionMethod string          `json:"collection_method"`
+	Description      string          `json:"description"`
+	AmountDue        int64           `json:"amount_due"`
+	AmountPaid       int64           `json:"amount_paid"`
+	AmountRemaining  int64           `json:"amount_remaining"`
+	Currency         string          `json:"currency"`
+	DueDate          sql.NullTime    `json:"due_date"`
+	PaidOutOfBand    bool            `json:"paid_out_of_band"`
+	HostedInvoiceUrl string          `json:"hosted_invoice_url"`
+	InvoicePdf       string          `json:"invoice_pdf"`
+	CustomFields     json.RawMessage `json:"custom_fields"`
+	Metadata         json.RawMessage `json:"metadata"`
+	InvoicedAt       time.Time       `json:"invoiced_at"`
+	SyncedAt         time.Time       `json:"synced_at"`
+	CustomerName     string          `json:"customer_name"`
+	CustomerEmail    string          `json:"customer_email"`
+	Subtotal         int64           `json:"subtotal"`
+	Tax              int64           `json:"tax"`
+	Total            int64           `json:"total"`
+	Lines            json.RawMessage `json:"lines"`
+	TaxAmounts       json.RawMessage `json:"tax_amounts"`
+	TenantID         string          `json:"tenant_id"`
+}
+
+func (q *Queries) UpsertInvoice(ctx context.Context, db DBTX, arg UpsertInvoiceParams) error {
+	_, err := db.ExecContext(ctx, UpsertInvoice,
+		arg.ID,
+		arg.CustomerID,
+		arg.SubscriptionID,
+		arg.Number,
+		arg.Status,
+		arg.CollectionMethod,
+		arg.Description,
+		arg.AmountDue,
+		arg.AmountPaid,
+		arg.AmountRemaining,
+		arg.Currency,
+		arg.DueDate,
+		arg.PaidOutOfBand,
+		arg.HostedInvoiceUrl,
+		arg.InvoicePdf,
+		arg.CustomFields,
+		arg.Metadata,
+		arg.InvoicedAt,
+		arg.SyncedAt,
+		arg.CustomerName,
+		arg.CustomerEmail,
+		arg.Subtotal,
+		arg.Tax,
+		arg.Total,
+		arg.Lines,
+		arg.TaxAmounts,
+		arg.TenantID,
+	)
+	return err
+}
+
+const UpsertMetadataSchema = `-- name: UpsertMetadataSchema :one
+INSERT INTO metadata_schemas (
+    tenant_id, resource, schema
+) VALUES (
+    $1, $2, $3
+)
+ON CONFLICT (tenant_id, resource) DO UPDATE
+SET schema = EXCLUDED.schema
+RETURNING tenant_id, resource, schema, created_at, updated_at
+`
+
+type UpsertMetadataSchemaParams struct {
+	TenantID string          `json:"tenant_id"`
+	Resource string          `json:"resource"`
+	Schema   json.RawMessage `json:"schema"`
+}
+
+func (q *Queries) UpsertMetadataSchema(ctx context.Context, db DBTX, arg UpsertMetadataSchemaParams) (MetadataSchema, error) {
+	row := db.QueryRowContext(ctx, UpsertMetadataSchema, arg.TenantID, arg.Resource, arg.Schema)
+	var i MetadataSchema
+	err := row.Scan(
+		&i.TenantID,
+		&i.Resource,
+		&i.Schema,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const UpsertMirroredCharge = `-- name: UpsertMirroredCharge :exec
+INSERT INTO charges (
+    id, amount, amount_refunded, currency, status, captured, refunded, disputed,
+    customer_id, payment_method_id, invoice_id, description, metadata, created_at, synced_at, tenant_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16
+)
+ON CONFLICT (id) DO UPDATE
+SET amount = EXCLUDED.amount,
+    amount_refunded = EXCLUDED.amount_refunded,
+    currency = EXCLUDED.currency,
+    status = EXCLUDED.status,
+    captured = EXCLUDED.captured,
+    refunded = EXCLUDED.refunded,
+    disputed = EXCLUDED.disputed,
+    customer_id = EXCLUDED.customer_id,
+    payment_method_id = EXCLUDED.payment_method_id,
+    invoice_id = EXCLUDED.invoice_id,
+    description = EXCLUDED.description,
+    metadata = EXCLUDED.metadata,
+    synced_at = EXCLUDED.synced_at,
+    tenant_id = CASE WHEN EXCLUDED.tenant_id = 'default' THEN charges.tenant_id ELSE EXCLUDED.tenant_id END
+WHERE charges.synced_at <= EXCLUDED.synced_at
+`
+
+type UpsertMirroredChargeParams struct {
+	ID              string                `json:"id"`
+	Amount          int64                 `json:"amount"`
+	AmountRefunded  int64                 `json:"amount_refunded"`
+	Currency        string                `json:"currency"`
+	Status          string                `json:"status"`
+	Captured        bool                  `json:"captured"`
+	Refunded        bool                  `json:"refunded"`
+	Disputed        bool                  `json:"disputed"`
+	CustomerID      string                `json:"customer_id"`
+	PaymentMethodID sql.NullString        `json:"payment_method_id"`
+	InvoiceID       string                `json:"invoice_id"`
+	Description     sql.NullString        `json:"description"`
+	Metadata        pqtype.NullRawMessage `json:"metadata"`
+	CreatedAt       sql.NullTime          `json:"created_at"`
+	SyncedAt        time.Time             `json:"synced_at"`
+	TenantID        string                `json:"tenant_id"`
+}
+
+func (q *Queries) UpsertMirroredCharge(ctx context.Context, db DBTX, arg UpsertMirroredChargeParams) error {
+	_, err := db.ExecContext(ctx, UpsertMirroredCharge,
+		arg.ID,
+		arg.Amount,
+		arg.AmountRefunded,
+		arg.Currency,
+		arg.Status,
+		arg.Captured,
+		arg.Refunded,
+		arg.Disputed,
+		arg.CustomerID,
+		arg.PaymentMethodID,
+		arg.InvoiceID,
+		arg.Description,
+		arg.Metadata,
+		arg.CreatedAt,
+		arg.SyncedAt,
+		arg.TenantID,
+	)
+	return err
+}
+
+const UpsertMirroredCustomer = `-- name: UpsertMirroredCustomer :exec
+INSERT INTO customers (
+    id, email, name, phone, description, metadata, created_at, synced_at, tenant_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+ON CONFLICT (id) DO UPDATE
+SET email = EXCLUDED.email,
+    name = EXCLUDED.name,
+    phone = EXCLUDED.phone,
+    description = EXCLUDED.description,
+    metadata = EXCLUDED.metadata,
+    synced_at = EXCLUDED.synced_at,
+    tenant_id = CASE WHEN EXCLUDED.tenant_id = 'default' THEN customers.tenant_id ELSE EXCLUDED.tenant_id END
+WHERE customers.synced_at <= EXCLUDED.synced_at
+  AND NOT EXISTS (SELECT 1 FROM customer_erasures WHERE customer_erasures.customer_id = customers.id)
+`
+
+type UpsertMirroredCustomerParams struct {
+	ID          string                `json:"id"`
+	Email       string                `json:"email"`
+	Name        string                `json:"name"`
+	Phone       sql.NullString        `json:"phone"`
+	Description sql.NullString        `json:"description"`
+	Metadata    pqtype.NullRawMessage `json:"metadata"`
+	CreatedAt   sql.NullTime          `json:"created_at"`
+	SyncedAt    time.Time             `json:"synced_at"`
+	TenantID    string                `json:"tenant_id"`
+}
+
+func (q *Queries) UpsertMirroredCustomer(ctx context.Context, db DBTX, arg UpsertMirroredCustomerParams) error {
+	_, err := db.ExecContext(ctx, UpsertMirroredCustomer,
+		arg.ID,
+		arg.Email,
+		arg.Name,
+		arg.Phone,
+		arg.Description,
+		arg.Metadata,
+		arg.CreatedAt,
+		arg.SyncedAt,
+		arg.TenantID,
+	)
+	return err
+}
+
+const UpsertMirroredPaymentMethod = `-- name: UpsertMirroredPaymentMethod :exec
+INSERT INTO payment_methods (
+    id, type, customer_id, card_last4, card_brand, card_exp_month, card_exp_year, card_fingerprint, metadata, created_at, synced_at, tenant_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+)
+ON CONFLICT (id) DO UPDATE
+SET type = EXCLUDED.type,
+    customer_id = EXCLUDED.customer_id,
+    card_last4 = EXCLUDED.card_last4,
+    card_brand = EXCLUDED.card_brand,
+    card_exp_month = EXCLUDED.card_exp_month,
+    card_exp_year = EXCLUDED.card_exp_year,
+    card_fingerprint = EXCLUDED.card_fingerprint,
+    metadata = EXCLUDED.metadata,
+    synced_at = EXCLUDED.synced_at,
+    tenant_id = CASE WHEN EXCLUDED.tenant_id = 'default' THEN payment_methods.tenant_id ELSE EXCLUDED.tenant_id END
+WHERE payment_methods.synced_at <= EXCLUDED.synced_at
+`
+
+type UpsertMirroredPaymentMethodParams struct {
+	ID              string                `json:"id"`
+	Type            string                `json:"type"`
+	CustomerID      string                `json:"customer_id"`
+	CardLast4       sql.NullString        `json:"card_last4"`
+	CardBrand       sql.NullString        `json:"card_brand"`
+	CardExpMonth    sql.NullInt32         `json:"card_exp_month"`
+	CardExpYear     sql.NullInt32         `json:"card_exp_year"`
+	CardFingerprint sql.NullString        `json:"card_fingerprint"`
+	Metadata        pqtype.NullRawMessage `json:"metadata"`
+	CreatedAt       sql.NullTime          `json:"created_at"`
+	SyncedAt        time.Time             `json:"synced_at"`
+	TenantID        string                `json:"tenant_id"`
+}
+
+func (q *Queries) UpsertMirroredPaymentMethod(ctx context.Context, db DBTX, arg UpsertMirroredPaymentMethodParams) error {
+	_, err := db.ExecContext(ctx, UpsertMirroredPaymentMethod,
+		arg.ID,
+		arg.Type,
+		arg.CustomerID,
+		arg.CardLast4,
+		arg.CardBrand,
+		arg.CardExpMonth,
+		arg.CardExpYear,
+		arg.CardFingerprint,
+		arg.Metadata,
+		arg.CreatedAt,
+		arg.SyncedAt,
+		arg.TenantID,
+	)
+	return err
+}
+
+const UpsertMirroredRefund = `-- name: UpsertMirroredRefund :exec
+INSERT INTO refunds (
+    id, charge_id, amount, currency, status, reason, metadata, created_at, synced_at, tenant_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+)
+ON CONFLICT (id) DO UPDATE
+SET charge_id = EXCLUDED.charge_id,
+    amount = EXCLUDED.amount,
+    currency = EXCLUDED.currency,
+    status = EXCLUDED.status,
+    reason = EXCLUDED.reason,
+    metadata = EXCLUDED.metadata,
+    synced_at = EXCLUDED.synced_at,
+    tenant_id = CASE WHEN EXCLUDED.tenant_id = 'default' THEN refunds.tenant_id ELSE EXCLUDED.tenant_id END
+WHERE refunds.synced_at <= EXCLUDED.synced_at
+`
+
+type UpsertMirroredRefundParams struct {
+	ID        string                `json:"id"`
+	ChargeID  string                `json:"charge_id"`
+	Amount    int64                 `json:"amount"`
+	Currency  string                `json:"currency"`
+	Status    string                `json:"status"`
+	Reason    sql.NullString        `json:"reason"`
+	Metadata  pqtype.NullRawMessage `json:"metadata"`
+	CreatedAt sql.NullTime          `json:"created_at"`
+	SyncedAt  time.Time             `json:"synced_at"`
+	TenantID  string                `json:"tenant_id"`
+}
+
+func (q *Queries) UpsertMirroredRefund(ctx context.Context, db DBTX, arg UpsertMirroredRefundParams) error {
+	_, err := db.ExecContext(ctx, UpsertMirroredRefund,
+		arg.ID,
+		arg.ChargeID,
+		arg.Amount,
+		arg.Currency,
+		arg.Status,
+		arg.Reason,
+		arg.Metadata,
+		arg.CreatedAt,
+		arg.SyncedAt,
+		arg.TenantID,
+	)
+	return err
+}
+
+const UpsertPaymentMethodPreference = `-- name: UpsertPaymentMethodPreference :one
+INSERT INTO payment_method_preferences (
+    tenant_id, customer_id, default_payment_method, backup_payment_methods
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (tenant_id, customer_id) DO UPDATE
+SET default_payment_method = EXCLUDED.default_payment_method,
+    backup_payment_methods = EXCLUDED.backup_payment_methods
+RETURNING tenant_id, customer_id, default_payment_method, backup_payment_methods, created_at, updated_at
+`
+
+type UpsertPaymentMethodPreferenceParams struct {
+	TenantID             string          `json:"tenant_id"`
+	CustomerID           string          `json:"customer_id"`
+	DefaultPaymentMethod string          `json:"default_payment_method"`
+	BackupPaymentMethods json.RawMessage `json:"backup_payment_methods"`
+}
+
+func (q *Queries) UpsertPaymentMethodPreference(ctx context.Context, db DBTX, arg UpsertPaymentMethodPreferenceParams) (PaymentMethodPreference, error) {
+	row := db.QueryRowContext(ctx, UpsertPaymentMethodPreference,
+		arg.TenantID,
+		arg.CustomerID,
+		arg.DefaultPaymentMethod,
+		arg.BackupPaymentMethods,
+	)
+	var i PaymentMethodPreference
+	err := row.Scan(
+		&i.TenantID,
+		&i.CustomerID,
+		&i.DefaultPaymentMethod,
+		&i.BackupPaymentMethods,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const UpsertProviderCredential = `-- name: UpsertProviderCredential :one
+INSERT INTO provider_credentials (
+    tenant_id, provider, ciphertext, masked_fields, version, verified_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+ON CONFLICT (tenant_id, provider) DO UPDATE
+SET ciphertext = EXCLUDED.ciphertext,
+    masked_fields = EXCLUDED.masked_fields,
+    version = EXCLUDED.version,
+    verified_at = EXCLUDED.verified_at
+RETURNING tenant_id, provider, ciphertext, masked_fields, version, verified_at, created_at, updated_at
+`
+
+type UpsertProviderCredentialParams struct {
+	TenantID     string          `json:"tenant_id"`
+	Provider     string          `json:"provider"`
+	Ciphertext   []byte          `json:"ciphertext"`
+	MaskedFields json.RawMessage `json:"masked_fields"`
+	Version      int32           `json:"version"`
+	VerifiedAt   time.Time       `json:"verified_at"`
+}
+
+func (q *Queries) UpsertProviderCredential(ctx context.Context, db DBTX, arg UpsertProviderCredentialParams) (ProviderCredential, error) {
+	row := db.QueryRowContext(ctx, UpsertProviderCredential,
+		arg.TenantID,
+		arg.Provider,
+		arg.Ciphertext,
+		arg.MaskedFields,
+		arg.Version,
+		arg.VerifiedAt,
+	)
+	var i ProviderCredential
+	err := row.Scan(
+		&i.TenantID,
+		&i.Provider,
+		&i.Ciphertext,
+		&i.MaskedFields,
+		&i.Version,
+		&i.VerifiedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const UpsertReceiptSettings = `-- name: UpsertReceiptSettings :one
+INSERT INTO receipt_settings (
+    tenant_id, send_receipt_emails, descriptor_suffix, business_name, support_email
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (tenant_id) DO UPDATE
+SET send_receipt_emails = EXCLUDED.send_receipt_emails,
+    descriptor_suffix = EXCLUDED.descriptor_suffix,
+    business_name = EXCLUDED.business_name,
+    support_email = EXCLUDED.support_email
+RETURNING tenant_id, send_receipt_emails, descriptor_suffix, business_name, support_email, created_at, updated_at
+`
+
+type UpsertReceiptSettingsParams struct {
+	TenantID          string `json:"tenant_id"`
+	SendReceiptEmails bool   `json:"send_receipt_emails"`
+	DescriptorSuffix  string `json:"descriptor_suffix"`
+	BusinessName      string `json:"business_name"`
+	SupportEmail      string `json:"support_email"`
+}
+
+func (q *Queries) UpsertReceiptSettings(ctx context.Context, db DBTX, arg UpsertReceiptSettingsParams) (ReceiptSetting, error) {
+	row := db.QueryRowContext(ctx, UpsertReceiptSettings,
+		arg.TenantID,
+		arg.SendReceiptEmails,
+		arg.DescriptorSuffix,
+		arg.BusinessName,
+		arg.SupportEmail,
+	)
+	var i ReceiptSetting
+	err := row.Scan(
+		&i.TenantID,
+		&i.SendReceiptEmails,
+		&i.DescriptorSuffix,
+		&i.BusinessName,
+		&i.SupportEmail,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const UpsertReceivableInvoice = `-- name: UpsertReceivableInvoice :one
+INSERT INTO receivable_invoices (
+    invoice_id, customer_id, subscription_id, number, amount_due, amount_remaining, currency, due_date, status
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+ON CONFLICT (invoice_id) DO UPDATE SET
+    number = EXCLUDED.number,
+    amount_due = EXCLUDED.amount_due,
+    amount_remaining = EXCLUDED.amount_remaining,
+    due_date = EXCLUDED.due_date,
+    status = EXCLUDED.status
+RETURNING invoice_id, customer_id, subscription_id, number, amount_due, amount_remaining, currency, due_date, status, overdue_at, paid_at, paid_reference, created_at, updated_at
+`
+
+type UpsertReceivableInvoiceParams struct {
+	InvoiceID       string    `json:"invoice_id"`
+	CustomerID      string    `json:"customer_id"`
+	SubscriptionID  string    `json:"subscription_id"`
+	Number          string    `json:"number"`
+	AmountDue       int64     `json:"amount_due"`
+	AmountRemaining int64     `json:"amount_remaining"`
+	Currency        string    `json:"currency"`
+	DueDate         time.Time `json:"due_date"`
+	Status          string    `json:"status"`
+}
+
+func (q *Queries) UpsertReceivableInvoice(ctx context.Context, db DBTX, arg UpsertReceivableInvoiceParams) (ReceivableInvoice, error) {
+	row := db.QueryRowContext(ctx, UpsertReceivableInvoice,
+		arg.InvoiceID,
+		arg.CustomerID,
+		arg.SubscriptionID,
+		arg.Number,
+		arg.AmountDue,
+		arg.AmountRemaining,
+		arg.Currency,
+		arg.DueDate,
+		arg.Status,
+	)
+	var i ReceivableInvoice
+	err := row.Scan(
+		&i.InvoiceID,
+		&i.CustomerID,
+		&i.SubscriptionID,
+		&i.Number,
+		&i.AmountDue,
+		&i.AmountRemaining,
+		&i.Currency,
+		&i.DueDate,
+		&i.Status,
+		&i.OverdueAt,
+		&i.PaidAt,
+		&i.PaidReference,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const UpsertSubscription = `-- name: UpsertSubscription :exec
+INSERT INTO subscriptions (
+    id, customer_id, plan_id, status, paused, cancel_at_period_end,
+    current_period_start, current_period_end, collection_method, days_until_due,
+    metadata, subscription_created_at, synced_at, tenant_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
+)
+ON CONFLICT (id) DO UPDATE
+SET customer_id = EXCLUDED.customer_id,
+    plan_id = EXCLUDED.plan_id,
+    status = EXCLUDED.status,
+    paused = EXCLUDED.paused,
+    cancel_at_period_end = EXCLUDED.cancel_at_period_end,
+    current_period_start = EXCLUDED.current_period_start,
+    current_period_end = EXCLUDED.current_period_end,
+    collection_method = EXCLUDED.collection_method,
+    days_until_due = EXCLUDED.days_until_due,
+    metadata = EXCLUDED.metadata,
+    synced_at = EXCLUDED.synced_at,
+    tenant_id = CASE WHEN EXCLUDED.tenant_id = 'default' THEN subscriptions.tenant_id ELSE EXCLUDED.tenant_id END
+WHERE subscriptions.synced_at <= EXCLUDED.synced_at
+`
+
+type UpsertSubscriptionParams struct {
+	ID                    string          `json:"id"`
+	CustomerID            string          `json:"customer_id"`
+	PlanID                string          `json:"plan_id"`
+	Status                string          `json:"status"`
+	Paused                bool            `json:"paused"`
+	CancelAtPeriodEnd     bool            `json:"cancel_at_period_end"`
+	CurrentPeriodStart    time.Time       `json:"current_period_start"`
+	CurrentPeriodEnd      time.Time       `json:"current_period_end"`
+	CollectionMethod      string          `json:"collection_method"`
+	DaysUntilDue          int64           `json:"days_until_due"`
+	Metadata              json.RawMessage `json:"metadata"`
+	SubscriptionCreatedAt time.Time       `json:"subscription_created_at"`
+	SyncedAt              time.Time       `json:"synced_at"`
+	TenantID              string          `json:"tenant_id"`
+}
+
+func (q *Queries) UpsertSubscription(ctx context.Context, db DBTX, arg UpsertSubscriptionParams) error {
+	_, err := db.ExecContext(ctx, UpsertSubscription,
+		arg.ID,
+		arg.CustomerID,
+		arg.PlanID,
+		arg.Status,
+		arg.Paused,
+		arg.CancelAtPeriodEnd,
+		arg.CurrentPeriodStart,
+		arg.CurrentPeriodEnd,
+		arg.CollectionMethod,
+		arg.DaysUntilDue,
+		arg.Metadata,
+		arg.SubscriptionCreatedAt,
+		arg.SyncedAt,
+		arg.TenantID,
+	)
+	return err
+}
+
+const UpsertSubscriptionPlan = `-- name: UpsertSubscriptionPlan :exec
+INSERT INTO subscription_plans (
+    id, product_id, nickname, amount, currency, billing_interval, interval_count,
+    active, metadata, plan_created_at, synced_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+)
+ON CONFLICT (id) DO UPDATE
+SET product_id = EXCLUDED.product_id,
+    nickname = EXCLUDED.nickname,
+    amount = EXCLUDED.amount,
+    currency = EXCLUDED.currency,
+    billing_interval = EXCLUDED.billing_interval,
+    interval_count = EXCLUDED.interval_count,
+    active = EXCLUDED.active,
+    metadata = EXCLUDED.metadata,
+    synced_at = EXCLUDED.synced_at
+WHERE subscription_plans.synced_at <= EXCLUDED.synced_at
+`
+
+type UpsertSubscriptionPlanParams struct {
+	ID              string          `json:"id"`
+	ProductID       string          `json:"product_id"`
+	Nickname        string          `json:"nickname"`
+	Amount          int64           `json:"amount"`
+	Currency        string          `json:"currency"`
+	BillingInterval string          `json:"billing_interval"`
+	IntervalCount   int64           `json:"interval_count"`
+	Active          bool            `json:"active"`
+	Metadata        json.RawMessage `json:"metadata"`
+	PlanCreatedAt   time.Time       `json:"plan_created_at"`
+	SyncedAt        time.Time       `json:"synced_at"`
+}
+
+func (q *Queries) UpsertSubscriptionPlan(ctx context.Context, db DBTX, arg UpsertSubscriptionPlanParams) error {
+	_, err := db.ExecContext(ctx, UpsertSubscriptionPlan,
+		arg.ID,
+		arg.ProductID,
+		arg.Nickname,
+		arg.Amount,
+		arg.Currency,
+		arg.BillingInterval,
+		arg.IntervalCount,
+		arg.Active,
+		arg.Metadata,
+		arg.PlanCreatedAt,
+		arg.SyncedAt,
+	)
+	return err
+}
+
+const UpsertTenantBudget = `-- name: UpsertTenantBudget :one
+INSERT INTO tenant_budgets (
+    tenant_id, monthly_limit, thresholds, hard_stop, updated_by
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (tenant_id) DO UPDATE
+SET monthly_limit = EXCLUDED.monthly_limit,
+    thresholds = EXCLUDED.thresholds,
+    hard_stop = EXCLUDED.hard_stop,
+    updated_by = EXCLUDED.updated_by
+RETURNING tenant_id, monthly_limit, thresholds, hard_stop, updated_by, created_at, updated_at
+`
+
+type UpsertTenantBudgetParams struct {
+	TenantID     string          `json:"tenant_id"`
+	MonthlyLimit int64           `json:"monthly_limit"`
+	Thresholds   json.RawMessage `json:"thresholds"`
+	HardStop     bool            `json:"hard_stop"`
+	UpdatedBy    string          `json:"updated_by"`
+}
+
+func (q *Queries) UpsertTenantBudget(ctx context.Context, db DBTX, arg UpsertTenantBudgetParams) (TenantBudget, error) {
+	row := db.QueryRowContext(ctx, UpsertTenantBudget,
+		arg.TenantID,
+		arg.MonthlyLimit,
+		arg.Thresholds,
+		arg.HardStop,
+		arg.UpdatedBy,
+	)
+	var i TenantBudget
+	err := row.Scan(
+		&i.TenantID,
+		&i.MonthlyLimit,
+		&i.Thresholds,
+		&i.HardStop,
+		&i.UpdatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const UpsertTenantConfig = `-- name: UpsertTenantConfig :one
+INSERT INTO tenant_configs (
+    tenant_id, name, status, default_provider
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (tenant_id) DO UPDATE
+SET name = EXCLUDED.name,
+    status = EXCLUDED.status,
+    default_provider = EXCLUDED.default_provider
+RETURNING tenant_id, name, status, default_provider, created_at, updated_at
+`
+
+type UpsertTenantConfigParams struct {
+	TenantID        string `json:"tenant_id"`
+	Name            string `json:"name"`
+	Status          string `json:"status"`
+	DefaultProvider string `json:"default_provider"`
+}
+
+func (q *Queries) UpsertTenantConfig(ctx context.Context, db DBTX, arg UpsertTenantConfigParams) (TenantConfig, error) {
+	row := db.QueryRowContext(ctx, UpsertTenantConfig,
+		arg.TenantID,
+		arg.Name,
+		arg.Status,
+		arg.DefaultProvider,
+	)
+	var i TenantConfig
+	err := row.Scan(
+		&i.TenantID,
+		&i.Name,
+		&i.Status,
+		&i.DefaultProvider,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const UpsertUnclaimedBalance = `-- name: UpsertUnclaimedBalance :exec
+INSERT INTO unclaimed_balances (
+    customer_id, currency, amount, funded_at
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (customer_id, currency) DO UPDATE SET
+    amount = EXCLUDED.amount,
+    funded_at = EXCLUDED.funded_at
+`
+
+type UpsertUnclaimedBalanceParams struct {
+	CustomerID string       `json:"customer_id"`
+	Currency   string       `json:"currency"`
+	Amount     int64        `json:"amount"`
+	FundedAt   sql.NullTime `json:"funded_at"`
+}
+
+func (q *Queries) UpsertUnclaimedBalance(ctx context.Context, db DBTX, arg UpsertUnclaimedBalanceParams) error {
+	_, err := db.ExecContext(ctx, UpsertUnclaimedBalance,
+		arg.CustomerID,
+		arg.Currency,
+		arg.Amount,
+		arg.FundedAt,
+	)
+	return err
+}
@@ -0,0 +1,189 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/mirror"
+	"apis/payments/services/stripe"
+	"apis/payments/services/tenancy"
+)
+
+// UpsertSubscription stores a provider subscription unless a newer version is already stored
+func (r *Repository) UpsertSubscription(ctx context.Context, subscription *stripe.Subscription, syncedAt time.Time) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.UpsertSubscription")
+	defer span.End()
+
+	metadata, err := json.Marshal(subscription.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal subscription metadata: %w", err)
+	}
+
+	params := sqlc.UpsertSubscriptionParams{
+		ID:                    subscription.ID,
+		CustomerID:            subscription.CustomerID,
+		PlanID:                subscription.PriceID,
+		Status:                subscription.Status,
+		Paused:                subscription.Paused,
+		CancelAtPeriodEnd:     subscription.CancelAtPeriodEnd,
+		CurrentPeriodStart:    time.Unix(subscription.CurrentPeriodStart, 0).UTC(),
+		CurrentPeriodEnd:      time.Unix(subscription.CurrentPeriodEnd, 0).UTC(),
+		CollectionMethod:      subscription.CollectionMethod,
+		DaysUntilDue:          subscription.DaysUntilDue,
+		Metadata:              metadata,
+		SubscriptionCreatedAt: time.Unix(subscription.Created, 0).UTC(),
+		SyncedAt:              syncedAt,
+		TenantID:              tenancy.ID(ctx),
+	}
+
+	if err := r.queries.UpsertSubscription(ctx, r.db, params); err != nil {
+		return fmt.Errorf("failed to upsert subscription: %w", err)
+	}
+
+	return nil
+}
+
+// GetSubscription retrieves a stored subscription by ID
+func (r *Repository) GetSubscription(ctx context.Context, subscriptionID string) (*stripe.Subscription, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetSubscription")
+	defer span.End()
+
+	dbSubscription, err := r.queries.GetSubscription(ctx, r.db, sqlc.GetSubscriptionParams{
+		ID:       subscriptionID,
+		TenantID: scopedTenant(ctx),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscription: %w", err)
+	}
+
+	return convertSubscription(dbSubscription), nil
+}
+
+// ListSubscriptions retrieves stored subscriptions matching a filter, newest first
+func (r *Repository) ListSubscriptions(ctx context.Context, filter mirror.SubscriptionFilter) ([]*stripe.Subscription, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListSubscriptions")
+	defer span.End()
+
+	params := sqlc.ListSubscriptionsParams{
+		CustomerID: filter.CustomerID,
+		Status:     filter.Status,
+		Limit:      int32(filter.Limit),
+	}
+
+	dbSubscriptions, err := r.queries.ListSubscriptions(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+
+	subscriptions := make([]*stripe.Subscription, len(dbSubscriptions))
+	for i, dbSubscription := range dbSubscriptions {
+		subscriptions[i] = convertSubscription(dbSubscription)
+	}
+
+	return subscriptions, nil
+}
+
+// UpsertSubscriptionPlan stores a provider plan unless a newer version is already stored
+func (r *Repository) UpsertSubscriptionPlan(ctx context.Context, plan *stripe.SubscriptionPlan, syncedAt time.Time) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.UpsertSubscriptionPlan")
+	defer span.End()
+
+	metadata, err := json.Marshal(plan.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal plan metadata: %w", err)
+	}
+
+	params := sqlc.UpsertSubscriptionPlanParams{
+		ID:              plan.ID,
+		ProductID:       plan.ProductID,
+		Nickname:        plan.Nickname,
+		Amount:          plan.Amount,
+		Currency:        plan.Currency,
+		BillingInterval: plan.Interval,
+		IntervalCount:   plan.IntervalCount,
+		Active:          plan.Active,
+		Metadata:        metadata,
+		PlanCreatedAt:   time.Unix(plan.Created, 0).UTC(),
+		SyncedAt:        syncedAt,
+	}
+
+	if err := r.queries.UpsertSubscriptionPlan(ctx, r.db, params); err != nil {
+		return fmt.Errorf("failed to upsert subscription plan: %w", err)
+	}
+
+	return nil
+}
+
+// GetSubscriptionPlan retrieves a stored plan by ID
+func (r *Repository) GetSubscriptionPlan(ctx context.Context, planID string) (*stripe.SubscriptionPlan, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetSubscriptionPlan")
+	defer span.End()
+
+	dbPlan, err := r.queries.GetSubscriptionPlan(ctx, r.db, planID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscription plan: %w", err)
+	}
+
+	return convertSubscriptionPlan(dbPlan), nil
+}
+
+// ListSubscriptionPlans retrieves stored plans, optionally of one product, newest first
+func (r *Repository) ListSubscriptionPlans(ctx context.Context, productID string) ([]*stripe.SubscriptionPlan, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListSubscriptionPlans")
+	defer span.End()
+
+	dbPlans, err := r.queries.ListSubscriptionPlans(ctx, r.db, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscription plans: %w", err)
+	}
+
+	plans := make([]*stripe.SubscriptionPlan, len(dbPlans))
+	for i, dbPlan := range dbPlans {
+		plans[i] = convertSubscriptionPlan(dbPlan)
+	}
+
+	return plans, nil
+}
+
+// convertSubscription converts a database subscription to a service subscription
+func convertSubscription(dbSubscription sqlc.Subscription) *stripe.Subscription {
+	subscription := &stripe.Subscription{
+		ID:                 dbSubscription.ID,
+		CustomerID:         dbSubscription.CustomerID,
+		PriceID:            dbSubscription.PlanID,
+		Status:             dbSubscription.Status,
+		Paused:             dbSubscription.Paused,
+		CancelAtPeriodEnd:  dbSubscription.CancelAtPeriodEnd,
+		CurrentPeriodStart: dbSubscription.CurrentPeriodStart.Unix(),
+		CurrentPeriodEnd:   dbSubscription.CurrentPeriodEnd.Unix(),
+		CollectionMethod:   dbSubscription.CollectionMethod,
+		DaysUntilDue:       dbSubscription.DaysUntilDue,
+		Created:            dbSubscription.SubscriptionCreatedAt.Unix(),
+	}
+
+	_ = json.Unmarshal(dbSubscription.Metadata, &subscription.Metadata)
+
+	return subscription
+}
+
+// convertSubscriptionPlan converts a database plan to a service plan
+func convertSubscriptionPlan(dbPlan sqlc.SubscriptionPlan) *stripe.SubscriptionPlan {
+	plan := &stripe.SubscriptionPlan{
+		ID:            dbPlan.ID,
+		ProductID:     dbPlan.ProductID,
+		Nickname:      dbPlan.Nickname,
+		Amount:        dbPlan.Amount,
+		Currency:      dbPlan.Currency,
+		Interval:      dbPlan.BillingInterval,
+		IntervalCount: dbPlan.IntervalCount,
+		Active:        dbPlan.Active,
+		Created:       dbPlan.PlanCreatedAt.Unix(),
+	}
+
+	_ = json.Unmarshal(dbPlan.Metadata, &plan.Metadata)
+
+	return plan
+}
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/tenancy"
+)
+
+// GetTenantConfig retrieves a tenant's configuration
+func (r *Repository) GetTenantConfig(ctx context.Context, tenantID string) (*tenancy.Tenant, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetTenantConfig")
+	defer span.End()
+
+	dbTenant, err := r.queries.GetTenantConfig(ctx, r.db, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant config: %w", err)
+	}
+
+	return convertTenantConfig(dbTenant), nil
+}
+
+// ListTenantConfigs retrieves every tenant configuration
+func (r *Repository) ListTenantConfigs(ctx context.Context) ([]*tenancy.Tenant, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListTenantConfigs")
+	defer span.End()
+
+	dbTenants, err := r.queries.ListTenantConfigs(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenant configs: %w", err)
+	}
+
+	tenants := make([]*tenancy.Tenant, len(dbTenants))
+	for i, dbTenant := range dbTenants {
+		tenants[i] = convertTenantConfig(dbTenant)
+	}
+
+	return tenants, nil
+}
+
+// UpsertTenantConfig creates or replaces a tenant's configuration
+func (r *Repository) UpsertTenantConfig(ctx context.Context, tenant *tenancy.Tenant) (*tenancy.Tenant, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.UpsertTenantConfig")
+	defer span.End()
+
+	params := sqlc.UpsertTenantConfigParams{
+		TenantID:        tenant.ID,
+		Name:            tenant.Name,
+		Status:          tenant.Status,
+		DefaultProvider: tenant.DefaultProvider,
+	}
+
+	dbTenant, err := r.queries.UpsertTenantConfig(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert tenant config: %w", err)
+	}
+
+	return convertTenantConfig(dbTenant), nil
+}
+
+// GetChargeTenant retrieves the tenant of a locally stored charge
+func (r *Repository) GetChargeTenant(ctx context.Context, chargeID string) (string, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetChargeTenant")
+	defer span.End()
+
+	tenantID, err := r.queries.GetChargeTenant(ctx, r.db, chargeID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get charge tenant: %w", err)
+	}
+
+	return tenantID, nil
+}
+
+// scopedTenant returns the tenant a query is limited to, or "" for contexts
+// acting for no tenant, whose queries see every tenant's records
+func scopedTenant(ctx context.Context) string {
+	tenantID, _ := tenancy.FromContext(ctx)
+	return tenantID
+}
+
+// convertTenantConfig converts a database tenant configuration to a service tenant
+func convertTenantConfig(dbTenant sqlc.TenantConfig) *tenancy.Tenant {
+	return &tenancy.Tenant{
+		ID:              dbTenant.TenantID,
+		Name:            dbTenant.Name,
+		Status:          dbTenant.Status,
+		DefaultProvider: dbTenant.DefaultProvider,
+		CreatedAt:       dbTenant.CreatedAt.Time,
+		UpdatedAt:       dbTenant.UpdatedAt.Time,
+	}
+}
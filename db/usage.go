@@ -0,0 +1,163 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/usage"
+)
+
+// InsertUsageRecord stores a usage record, returning false if a record with
+// the same idempotency key already exists for the subscription
+func (r *Repository) InsertUsageRecord(ctx context.Context, record *usage.Record) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.InsertUsageRecord")
+	defer span.End()
+
+	rows, err := r.queries.InsertUsageRecord(ctx, r.db, sqlc.InsertUsageRecordParams{
+		ID:                 record.ID,
+		SubscriptionID:     record.SubscriptionID,
+		SubscriptionItemID: record.SubscriptionItemID,
+		PriceID:            record.PriceID,
+		Quantity:           record.Quantity,
+		Action:             record.Action,
+		RecordedAt:         record.RecordedAt,
+		IdempotencyKey:     record.IdempotencyKey,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to insert usage record: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// GetUsageRecord retrieves a usage record by ID
+func (r *Repository) GetUsageRecord(ctx context.Context, id string) (*usage.Record, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetUsageRecord")
+	defer span.End()
+
+	dbRecord, err := r.queries.GetUsageRecord(ctx, r.db, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage record: %w", err)
+	}
+
+	return convertUsageRecord(dbRecord), nil
+}
+
+// GetUsageRecordByKey retrieves the usage record reported for a subscription
+// with an idempotency key
+func (r *Repository) GetUsageRecordByKey(ctx context.Context, subscriptionID, idempotencyKey string) (*usage.Record, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetUsageRecordByKey")
+	defer span.End()
+
+	dbRecord, err := r.queries.GetUsageRecordByKey(ctx, r.db, sqlc.GetUsageRecordByKeyParams{
+		SubscriptionID: subscriptionID,
+		IdempotencyKey: idempotencyKey,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage record: %w", err)
+	}
+
+	return convertUsageRecord(dbRecord), nil
+}
+
+// ListUsageRecords retrieves a subscription's usage records used from from
+// until to, in the order they were used
+func (r *Repository) ListUsageRecords(ctx context.Context, subscriptionID string, from, to time.Time) ([]*usage.Record, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListUsageRecords")
+	defer span.End()
+
+	dbRecords, err := r.queries.ListUsageRecords(ctx, r.db, sqlc.ListUsageRecordsParams{
+		SubscriptionID: subscriptionID,
+		RecordedFrom:   from,
+		RecordedTo:     to,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list usage records: %w", err)
+	}
+
+	return convertUsageRecords(dbRecords), nil
+}
+
+// ListUnreportedUsageRecords retrieves records not yet pushed to the
+// provider with attempts left, oldest first
+func (r *Repository) ListUnreportedUsageRecords(ctx context.Context, maxAttempts, limit int) ([]*usage.Record, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListUnreportedUsageRecords")
+	defer span.End()
+
+	dbRecords, err := r.queries.ListUnreportedUsageRecords(ctx, r.db, sqlc.ListUnreportedUsageRecordsParams{
+		MaxAttempts: int32(maxAttempts),
+		BatchSize:   int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unreported usage records: %w", err)
+	}
+
+	return convertUsageRecords(dbRecords), nil
+}
+
+// MarkUsageRecordReported records that a usage record was pushed to the provider
+func (r *Repository) MarkUsageRecordReported(ctx context.Context, id, providerRecordID string, reportedAt time.Time) (*usage.Record, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.MarkUsageRecordReported")
+	defer span.End()
+
+	dbRecord, err := r.queries.MarkUsageRecordReported(ctx, r.db, sqlc.MarkUsageRecordReportedParams{
+		ID:               id,
+		ReportedAt:       sql.NullTime{Time: reportedAt, Valid: true},
+		ProviderRecordID: providerRecordID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark usage record reported: %w", err)
+	}
+
+	return convertUsageRecord(dbRecord), nil
+}
+
+// RecordUsageReportFailure counts a failed push of a usage record
+func (r *Repository) RecordUsageReportFailure(ctx context.Context, id, message string) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.RecordUsageReportFailure")
+	defer span.End()
+
+	if err := r.queries.RecordUsageReportFailure(ctx, r.db, sqlc.RecordUsageReportFailureParams{
+		ID:        id,
+		LastError: message,
+	}); err != nil {
+		return fmt.Errorf("failed to record usage report failure: %w", err)
+	}
+
+	return nil
+}
+
+func convertUsageRecords(dbRecords []sqlc.UsageRecord) []*usage.Record {
+	records := make([]*usage.Record, len(dbRecords))
+	for i, dbRecord := range dbRecords {
+		records[i] = convertUsageRecord(dbRecord)
+	}
+	return records
+}
+
+func convertUsageRecord(dbRecord sqlc.UsageRecord) *usage.Record {
+	record := &usage.Record{
+		ID:                 dbRecord.ID,
+		SubscriptionID:     dbRecord.SubscriptionID,
+		SubscriptionItemID: dbRecord.SubscriptionItemID,
+		PriceID:            dbRecord.PriceID,
+		Quantity:           dbRecord.Quantity,
+		Action:             dbRecord.Action,
+		RecordedAt:         dbRecord.RecordedAt,
+		IdempotencyKey:     dbRecord.IdempotencyKey,
+		ProviderRecordID:   dbRecord.ProviderRecordID,
+		Attempts:           int(dbRecord.Attempts),
+		LastError:          dbRecord.LastError,
+		CreatedAt:          dbRecord.CreatedAt.Time,
+	}
+
+	if dbRecord.ReportedAt.Valid {
+		reportedAt := dbRecord.ReportedAt.Time
+		record.ReportedAt = &reportedAt
+	}
+
+	return record
+}
@@ -0,0 +1,138 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/tenancy"
+	"apis/payments/services/vault"
+)
+
+// CreateVaultToken stores a vault token, returning the existing one if the provider token is already vaulted
+func (r *Repository) CreateVaultToken(ctx context.Context, token *vault.Token) (*vault.Token, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.CreateVaultToken")
+	defer span.End()
+
+	params := sqlc.CreateVaultTokenParams{
+		ID:            token.ID,
+		Provider:      token.Provider,
+		ProviderToken: token.ProviderToken,
+		CustomerID:    token.CustomerID,
+		Type:          token.Type,
+		Fingerprint:   token.Fingerprint,
+		TenantID:      tenancy.ID(ctx),
+	}
+
+	dbToken, err := r.queries.CreateVaultToken(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create vault token: %w", err)
+	}
+
+	return convertVaultToken(dbToken), nil
+}
+
+// GetVaultToken retrieves a vault token by ID
+func (r *Repository) GetVaultToken(ctx context.Context, id string) (*vault.Token, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetVaultToken")
+	defer span.End()
+
+	dbToken, err := r.queries.GetVaultToken(ctx, r.db, sqlc.GetVaultTokenParams{
+		ID:       id,
+		TenantID: scopedTenant(ctx),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vault token: %w", err)
+	}
+
+	return convertVaultToken(dbToken), nil
+}
+
+// GetVaultTokenByProviderToken retrieves the vault token for a provider payment method
+func (r *Repository) GetVaultTokenByProviderToken(ctx context.Context, provider, providerToken string) (*vault.Token, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetVaultTokenByProviderToken")
+	defer span.End()
+
+	params := sqlc.GetVaultTokenByProviderTokenParams{Provider: provider, ProviderToken: providerToken}
+	dbToken, err := r.queries.GetVaultTokenByProviderToken(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vault token by provider token: %w", err)
+	}
+
+	return convertVaultToken(dbToken), nil
+}
+
+// ListVaultTokensByCustomer retrieves every vault token issued for a customer
+func (r *Repository) ListVaultTokensByCustomer(ctx context.Context, customerID string) ([]*vault.Token, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListVaultTokensByCustomer")
+	defer span.End()
+
+	dbTokens, err := r.queries.ListVaultTokensByCustomer(ctx, r.db, sqlc.ListVaultTokensByCustomerParams{
+		CustomerID: customerID,
+		TenantID:   scopedTenant(ctx),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list vault tokens: %w", err)
+	}
+
+	tokens := make([]*vault.Token, len(dbTokens))
+	for i, dbToken := range dbTokens {
+		tokens[i] = convertVaultToken(dbToken)
+	}
+
+	return tokens, nil
+}
+
+// RemapVaultToken points a vault token at another provider's payment method
+func (r *Repository) RemapVaultToken(ctx context.Context, id, provider, providerToken string) (*vault.Token, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.RemapVaultToken")
+	defer span.End()
+
+	params := sqlc.RemapVaultTokenParams{ID: id, Provider: provider, ProviderToken: providerToken}
+	dbToken, err := r.queries.RemapVaultToken(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to remap vault token: %w", err)
+	}
+
+	return convertVaultToken(dbToken), nil
+}
+
+// UpdateVaultTokenFingerprint sets the card fingerprint of the vault token
+// for a provider's payment method
+func (r *Repository) UpdateVaultTokenFingerprint(ctx context.Context, provider, providerToken, fingerprint string) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.UpdateVaultTokenFingerprint")
+	defer span.End()
+
+	params := sqlc.UpdateVaultTokenFingerprintParams{Provider: provider, ProviderToken: providerToken, Fingerprint: fingerprint}
+	if err := r.queries.UpdateVaultTokenFingerprint(ctx, r.db, params); err != nil {
+		return fmt.Errorf("failed to update vault token fingerprint: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteVaultToken deletes a vault token
+func (r *Repository) DeleteVaultToken(ctx context.Context, id string) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.DeleteVaultToken")
+	defer span.End()
+
+	if err := r.queries.DeleteVaultToken(ctx, r.db, id); err != nil {
+		return fmt.Errorf("failed to delete vault token: %w", err)
+	}
+
+	return nil
+}
+
+// convertVaultToken converts a database vault token to a service vault token
+func convertVaultToken(dbToken sqlc.VaultToken) *vault.Token {
+	return &vault.Token{
+		ID:            dbToken.ID,
+		Provider:      dbToken.Provider,
+		ProviderToken: dbToken.ProviderToken,
+		CustomerID:    dbToken.CustomerID,
+		Type:          dbToken.Type,
+		Fingerprint:   dbToken.Fingerprint,
+		CreatedAt:     dbToken.CreatedAt.Time,
+		UpdatedAt:     dbToken.UpdatedAt.Time,
+	}
+}
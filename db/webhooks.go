@@ -0,0 +1,68 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"apis/payments/db/sqlc"
+
+	"github.com/sqlc-dev/pqtype"
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// IsEventProcessed reports whether a provider event has already been handled
+func (r *Repository) IsEventProcessed(ctx context.Context, eventID string) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.IsEventProcessed")
+	defer span.End()
+
+	_, err := r.queries.GetWebhookEvent(ctx, r.db, eventID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get webhook event: %w", err)
+	}
+
+	return true, nil
+}
+
+// RecordProcessedEvent stores a handled provider event with its payload
+func (r *Repository) RecordProcessedEvent(ctx context.Context, event stripego.Event, source string) error {
+	ctx, span := r.tracer.Start(ctx, "Repository.RecordProcessedEvent")
+	defer span.End()
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook event: %w", err)
+	}
+
+	params := sqlc.RecordWebhookEventParams{
+		ID:      event.ID,
+		Type:    string(event.Type),
+		Created: event.Created,
+		Source:  source,
+		Payload: pqtype.NullRawMessage{RawMessage: payload, Valid: true},
+	}
+
+	if err := r.queries.RecordWebhookEvent(ctx, r.db, params); err != nil {
+		return fmt.Errorf("failed to record webhook event: %w", err)
+	}
+
+	return nil
+}
+
+// LastProcessedEventTime returns the creation time of the newest handled event, or 0 if none
+func (r *Repository) LastProcessedEventTime(ctx context.Context) (int64, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.LastProcessedEventTime")
+	defer span.End()
+
+	lastCreated, err := r.queries.GetLastWebhookEventTime(ctx, r.db)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get last webhook event time: %w", err)
+	}
+
+	return lastCreated, nil
+}
@@ -0,0 +1,150 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"apis/payments/db/sqlc"
+	"apis/payments/services/webhooksecrets"
+)
+
+// CreateWebhookSecretRotation stores a new pending rotation
+func (r *Repository) CreateWebhookSecretRotation(ctx context.Context, rotation *webhooksecrets.Rotation) (*webhooksecrets.Rotation, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.CreateWebhookSecretRotation")
+	defer span.End()
+
+	dbRotation, err := r.queries.CreateWebhookSecretRotation(ctx, r.db, sqlc.CreateWebhookSecretRotationParams{
+		ID:            rotation.ID,
+		OldEndpointID: rotation.OldEndpointID,
+		NewEndpointID: rotation.NewEndpointID,
+		NewSecret:     rotation.Ciphertext,
+		GraceEndsAt:   rotation.GraceEndsAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook secret rotation: %w", err)
+	}
+
+	return convertWebhookSecretRotation(dbRotation), nil
+}
+
+// GetWebhookSecretRotation retrieves a rotation by ID
+func (r *Repository) GetWebhookSecretRotation(ctx context.Context, id string) (*webhooksecrets.Rotation, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetWebhookSecretRotation")
+	defer span.End()
+
+	dbRotation, err := r.queries.GetWebhookSecretRotation(ctx, r.db, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook secret rotation: %w", err)
+	}
+
+	return convertWebhookSecretRotation(dbRotation), nil
+}
+
+// GetPendingWebhookSecretRotation retrieves the rotation in progress
+func (r *Repository) GetPendingWebhookSecretRotation(ctx context.Context) (*webhooksecrets.Rotation, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetPendingWebhookSecretRotation")
+	defer span.End()
+
+	dbRotation, err := r.queries.GetPendingWebhookSecretRotation(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending webhook secret rotation: %w", err)
+	}
+
+	return convertWebhookSecretRotation(dbRotation), nil
+}
+
+// GetLatestCompletedWebhookSecretRotation retrieves the rotation whose new
+// secret is the current one
+func (r *Repository) GetLatestCompletedWebhookSecretRotation(ctx context.Context) (*webhooksecrets.Rotation, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.GetLatestCompletedWebhookSecretRotation")
+	defer span.End()
+
+	dbRotation, err := r.queries.GetLatestCompletedWebhookSecretRotation(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get completed webhook secret rotation: %w", err)
+	}
+
+	return convertWebhookSecretRotation(dbRotation), nil
+}
+
+// ListWebhookSecretRotations retrieves rotations, newest first
+func (r *Repository) ListWebhookSecretRotations(ctx context.Context, limit int) ([]*webhooksecrets.Rotation, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.ListWebhookSecretRotations")
+	defer span.End()
+
+	dbRotations, err := r.queries.ListWebhookSecretRotations(ctx, r.db, int32(limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook secret rotations: %w", err)
+	}
+
+	rotations := make([]*webhooksecrets.Rotation, len(dbRotations))
+	for i, dbRotation := range dbRotations {
+		rotations[i] = convertWebhookSecretRotation(dbRotation)
+	}
+	return rotations, nil
+}
+
+// RecordWebhookSecretRotationDelivery counts a delivery signed with a pending
+// rotation's new secret
+func (r *Repository) RecordWebhookSecretRotationDelivery(ctx context.Context, id string) (*webhooksecrets.Rotation, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.RecordWebhookSecretRotationDelivery")
+	defer span.End()
+
+	dbRotation, err := r.queries.RecordWebhookSecretRotationDelivery(ctx, r.db, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record webhook secret rotation delivery: %w", err)
+	}
+
+	return convertWebhookSecretRotation(dbRotation), nil
+}
+
+// FinishWebhookSecretRotation records a pending rotation's final status
+func (r *Repository) FinishWebhookSecretRotation(ctx context.Context, rotation *webhooksecrets.Rotation) (*webhooksecrets.Rotation, error) {
+	ctx, span := r.tracer.Start(ctx, "Repository.FinishWebhookSecretRotation")
+	defer span.End()
+
+	params := sqlc.FinishWebhookSecretRotationParams{
+		ID:     rotation.ID,
+		Status: rotation.Status,
+	}
+	if rotation.CompletedAt != nil {
+		params.CompletedAt = sql.NullTime{Time: *rotation.CompletedAt, Valid: true}
+	}
+	if rotation.CancelledAt != nil {
+		params.CancelledAt = sql.NullTime{Time: *rotation.CancelledAt, Valid: true}
+	}
+
+	dbRotation, err := r.queries.FinishWebhookSecretRotation(ctx, r.db, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to finish webhook secret rotation: %w", err)
+	}
+
+	return convertWebhookSecretRotation(dbRotation), nil
+}
+
+// convertWebhookSecretRotation converts a database rotation
+func convertWebhookSecretRotation(dbRotation sqlc.WebhookSecretRotation) *webhooksecrets.Rotation {
+	rotation := &webhooksecrets.Rotation{
+		ID:            dbRotation.ID,
+		Status:        dbRotation.Status,
+		OldEndpointID: dbRotation.OldEndpointID,
+		NewEndpointID: dbRotation.NewEndpointID,
+		Ciphertext:    dbRotation.NewSecret,
+		Deliveries:    int(dbRotation.Deliveries),
+		GraceEndsAt:   dbRotation.GraceEndsAt,
+		CreatedAt:     dbRotation.CreatedAt.Time,
+		UpdatedAt:     dbRotation.UpdatedAt.Time,
+	}
+	if dbRotation.ConfirmedAt.Valid {
+		rotation.ConfirmedAt = &dbRotation.ConfirmedAt.Time
+	}
+	if dbRotation.CompletedAt.Valid {
+		rotation.CompletedAt = &dbRotation.CompletedAt.Time
+	}
+	if dbRotation.CancelledAt.Valid {
+		rotation.CancelledAt = &dbRotation.CancelledAt.Time
+	}
+
+	return rotation
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"apis/payments/services/i18n"
+	"apis/payments/services/stripe"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// createConnectedAccount handles creating a connected account for a marketplace seller
+func (a *App) createConnectedAccount(c *fiber.Ctx) error {
+	var request stripe.CreateAccountRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	account, err := a.connectService.CreateAccount(c.Context(), &request)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(account)
+}
+
+// getConnectedAccount handles retrieving a connected account with its
+// onboarding status
+func (a *App) getConnectedAccount(c *fiber.Ctx) error {
+	account, err := a.connectService.GetAccount(c.Context(), c.Params("id"))
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusNotFound, err)
+	}
+
+	return c.JSON(account)
+}
+
+// listConnectedAccounts handles listing connected accounts
+func (a *App) listConnectedAccounts(c *fiber.Ctx) error {
+	page, err := pageRequest(c)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	accounts, err := a.connectService.ListAccounts(c.Context(), page)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	accounts, hasMore, nextCursor := trimPage(accounts, page, func(account *stripe.ConnectedAccount) string { return account.ID })
+	return c.JSON(pageResponse(accounts, hasMore, nextCursor))
+}
+
+// createOnboardingLink handles creating a link to Stripe-hosted onboarding
+// for a connected account
+func (a *App) createOnboardingLink(c *fiber.Ctx) error {
+	var request stripe.AccountLinkRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	link, err := a.connectService.CreateAccountLink(c.Context(), c.Params("id"), &request)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(link)
+}
+
+// createTransfer handles transferring platform funds to a connected account
+func (a *App) createTransfer(c *fiber.Ctx) error {
+	var request stripe.TransferRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+	request.Destination = c.Params("id")
+
+	transfer, err := a.connectService.CreateTransfer(c.Context(), &request)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(transfer)
+}
+
+// listTransfers handles listing the transfers made to a connected account
+func (a *App) listTransfers(c *fiber.Ctx) error {
+	page, err := pageRequest(c)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	transfers, err := a.connectService.ListTransfers(c.Context(), c.Params("id"), page)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	transfers, hasMore, nextCursor := trimPage(transfers, page, func(transfer *stripe.Transfer) string { return transfer.ID })
+	return c.JSON(pageResponse(transfers, hasMore, nextCursor))
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"crypto/subtle"
+	"log"
+	"os"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/keyauth"
+	"github.com/gofiber/fiber/v2/middleware/monitor"
+	"github.com/gofiber/fiber/v2/middleware/pprof"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+)
+
+// newAdminApp creates the admin server that exposes profiling and operator
+// endpoints. It returns nil when ADMIN_TOKEN is not set, so these are never
+// exposed without authentication.
+func (a *App) newAdminApp() *fiber.App {
+	token := os.Getenv("ADMIN_TOKEN")
+	if token == "" {
+		log.Println("ADMIN_TOKEN not set, admin server disabled")
+		return nil
+	}
+
+	adminApp := fiber.New(fiber.Config{
+		AppName:               "Payments Admin",
+		DisableStartupMessage: true,
+		ReadTimeout:           30 * time.Second,
+		// CPU profiles and execution traces stream for as long as requested
+		WriteTimeout: 5 * time.Minute,
+		IdleTimeout:  120 * time.Second,
+	})
+
+	adminApp.Use(recover.New())
+	adminApp.Use(keyauth.New(keyauth.Config{
+		Validator: func(c *fiber.Ctx, key string) (bool, error) {
+			if subtle.ConstantTimeCompare([]byte(key), []byte(token)) == 1 {
+				return true, nil
+			}
+			return false, keyauth.ErrMissingOrMalformedAPIKey
+		},
+	}))
+
+	// Operator mutations are audited like API mutations
+	adminApp.Use(a.auditCalls)
+
+	// net/http/pprof handlers, including /debug/pprof/trace?seconds=N for
+	// on-demand execution traces
+	adminApp.Use(pprof.New())
+
+	adminApp.Get("/debug/monitor", monitor.New(monitor.Config{
+		Title: "Payments Admin",
+	}))
+
+	// Per-operation provider call latency percentiles
+	adminApp.Get("/debug/gateway-latency", a.getGatewayLatency)
+	// Per-operation provider circuit breaker states
+	adminApp.Get("/debug/gateway-breakers", a.getGatewayBreakers)
+	// Hits and misses of cached customer and charge reads
+	adminApp.Get("/debug/cache", a.getCacheStats)
+	adminApp.Get("/config", a.getConfig)
+	adminApp.Post("/config/reload", a.reloadConfig)
+	adminApp.Post("/api-keys", a.createAPIKey)
+
+	// Operator routes
+	adminApp.Post("/webhooks/catch-up", a.catchUpWebhooks)
+	adminApp.Get("/webhooks/backpressure", a.getWebhookBackpressure)
+	adminApp.Get("/rate-limits/usage", a.getRateLimitUsage)
+	adminApp.Get("/webhooks/secret-rotations", a.listWebhookSecretRotations)
+	adminApp.Post("/webhooks/secret-rotations", a.rotateWebhookSecret)
+	adminApp.Get("/webhooks/secret-rotations/:id", a.getWebhookSecretRotation)
+	adminApp.Post("/webhooks/secret-rotations/:id/complete", a.completeWebhookSecretRotation)
+	adminApp.Post("/webhooks/secret-rotations/:id/cancel", a.cancelWebhookSecretRotation)
+	adminApp.Post("/projections/charges/rebuild", a.rebuildChargeRows)
+	adminApp.Get("/deprecations/usage", a.getDeprecationReport)
+	adminApp.Post("/auto-refunds/sweep", a.sweepAutoRefunds)
+	adminApp.Post("/reconciliation/run", a.runReconciliation)
+	adminApp.Post("/invoices/reminders/run", a.sendInvoiceReminders)
+	adminApp.Post("/dunning/run", a.runDunning)
+	adminApp.Post("/authorizations/sweep", a.sweepAuthorizations)
+	adminApp.Post("/charges/:id/state", a.setChargeState)
+	adminApp.Post("/vault/tokens/:id/remap", a.remapVaultToken)
+	adminApp.Get("/simulator/latency", a.getSimulatorLatency)
+	adminApp.Put("/simulator/latency", a.updateSimulatorLatency)
+	adminApp.Get("/budgets/:tenantId", a.getTenantBudget)
+	adminApp.Put("/budgets/:tenantId", a.updateTenantBudget)
+	adminApp.Delete("/budgets/:tenantId", a.deleteTenantBudget)
+	adminApp.Get("/hold-policies/:tenantId", a.getHoldPolicy)
+	adminApp.Put("/hold-policies/:tenantId", a.updateHoldPolicy)
+	adminApp.Get("/tenants", a.listTenantConfigs)
+	adminApp.Get("/tenants/:tenantId/config", a.getTenantConfig)
+	adminApp.Put("/tenants/:tenantId/config", a.updateTenantConfig)
+	adminApp.Post("/blocklist/sync", a.syncBlocklist)
+	adminApp.Get("/routing/rules", a.listRoutingRules)
+	adminApp.Post("/routing/rules", a.createRoutingRule)
+	adminApp.Get("/routing/rules/:id", a.getRoutingRule)
+	adminApp.Put("/routing/rules/:id", a.updateRoutingRule)
+	adminApp.Delete("/routing/rules/:id", a.deleteRoutingRule)
+	adminApp.Post("/routing/decisions", a.previewRoutingDecision)
+	adminApp.Get("/routing/auth-rates", a.getRoutingAuthRates)
+	adminApp.Get("/quarantines", a.listQuarantines)
+	adminApp.Post("/quarantines", a.createQuarantine)
+	adminApp.Get("/quarantines/:id", a.getQuarantine)
+	adminApp.Delete("/quarantines/:id", a.liftQuarantine)
+	adminApp.Get("/quarantines/:id/activity", a.listQuarantineActivity)
+	adminApp.Get("/held-mutations", a.listHeldMutations)
+	adminApp.Get("/held-mutations/:id", a.getHeldMutation)
+	adminApp.Post("/held-mutations/:id/release", a.releaseHeldMutation)
+	adminApp.Post("/held-mutations/:id/reject", a.rejectHeldMutation)
+	adminApp.Get("/dead-letters", a.listDeadLetters)
+	adminApp.Post("/dead-letters/retry", a.retryDueDeadLetters)
+	adminApp.Post("/dead-letters/purge", a.purgeDeadLetters)
+	adminApp.Get("/dead-letters/:id", a.getDeadLetter)
+	adminApp.Post("/dead-letters/:id/retry", a.retryDeadLetter)
+	adminApp.Delete("/dead-letters/:id", a.deleteDeadLetter)
+	adminApp.Get("/events", a.listArchivedEvents)
+	adminApp.Post("/events/replay", a.replayEvents)
+	adminApp.Get("/tenants/:tenantId/exports", a.listOffboardingExports)
+	adminApp.Post("/tenants/:tenantId/exports", a.createOffboardingExport)
+	adminApp.Get("/exports/:id", a.getOffboardingExport)
+	adminApp.Get("/exports/:id/archive", a.downloadOffboardingArchive)
+	adminApp.Post("/exports/:id/pan-migration", a.requestPANMigration)
+	adminApp.Get("/audit", a.listAuditEntries)
+	adminApp.Get("/audit/verify", a.verifyAuditLog)
+	adminApp.Get("/audit/:sequence", a.getAuditEntry)
+	adminApp.Get("/jobs", a.listJobs)
+	adminApp.Get("/jobs/:name/runs", a.listJobRuns)
+	adminApp.Post("/jobs/:name/run", a.runJob)
+
+	return adminApp
+}
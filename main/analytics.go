@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"apis/payments/services/analytics"
+	"apis/payments/services/batching"
+	"apis/payments/services/drain"
+	"apis/payments/services/i18n"
+	"apis/payments/services/kafka"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// errInvalidDate is returned for from and to values that aren't dates
+var errInvalidDate = errors.New("from and to must be dates (2006-01-02) or RFC 3339 times")
+
+// analyticsQuery reads the range, granularity and currency of an analytics
+// request for the caller's tenant. The range defaults to the last 30 days.
+func analyticsQuery(c *fiber.Ctx) (*analytics.Query, error) {
+	query := &analytics.Query{
+		TenantID:    requestTenant(c),
+		To:          time.Now().UTC(),
+		Granularity: analytics.Granularity(strings.ToLower(c.Query("granularity", string(analytics.GranularityDay)))),
+		Currency:    strings.ToLower(c.Query("currency")),
+	}
+
+	var err error
+	if to := c.Query("to"); to != "" {
+		if query.To, err = parseAnalyticsDate(to); err != nil {
+			return nil, err
+		}
+	}
+	query.From = query.To.AddDate(0, 0, -30)
+	if from := c.Query("from"); from != "" {
+		if query.From, err = parseAnalyticsDate(from); err != nil {
+			return nil, err
+		}
+	}
+
+	return query, nil
+}
+
+// parseAnalyticsDate parses a date, as midnight UTC, or an RFC 3339 time
+func parseAnalyticsDate(value string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errInvalidDate
+	}
+	return t.UTC(), nil
+}
+
+// analyticsErrorStatus maps analytics errors to HTTP status codes
+func analyticsErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errInvalidDate),
+		errors.Is(err, analytics.ErrInvalidRange),
+		errors.Is(err, analytics.ErrRangeTooLarge),
+		errors.Is(err, analytics.ErrInvalidGranularity):
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// analyticsReport serves a report broken into buckets per currency
+func analyticsReport[T any](a *App, c *fiber.Ctx, report func(query *analytics.Query) ([]*analytics.Series[T], error)) error {
+	if a.analytics == nil {
+		return a.errorMessage(c, fiber.StatusServiceUnavailable, "Analytics are not enabled", i18n.KeyGenericError)
+	}
+
+	query, err := analyticsQuery(c)
+	if err != nil {
+		return a.errorResponse(c, analyticsErrorStatus(err), err)
+	}
+
+	series, err := report(query)
+	if err != nil {
+		return a.errorResponse(c, analyticsErrorStatus(err), err)
+	}
+
+	return c.JSON(fiber.Map{
+		"tenant_id":   query.TenantID,
+		"granularity": query.Granularity,
+		"from":        query.From,
+		"to":          query.To,
+		"series":      series,
+	})
+}
+
+// getChargeAnalytics handles charge counts and success rates over time
+func (a *App) getChargeAnalytics(c *fiber.Ctx) error {
+	return analyticsReport(a, c, func(query *analytics.Query) ([]*analytics.Series[*analytics.ChargeBucket], error) {
+		return a.analytics.Charges(c.Context(), query)
+	})
+}
+
+// getRevenueAnalytics handles gross and net revenue over time
+func (a *App) getRevenueAnalytics(c *fiber.Ctx) error {
+	return analyticsReport(a, c, func(query *analytics.Query) ([]*analytics.Series[*analytics.RevenueBucket], error) {
+		return a.analytics.Revenue(c.Context(), query)
+	})
+}
+
+// getMRRAnalytics handles monthly recurring revenue over time
+func (a *App) getMRRAnalytics(c *fiber.Ctx) error {
+	return analyticsReport(a, c, func(query *analytics.Query) ([]*analytics.Series[*analytics.MRRBucket], error) {
+		return a.analytics.MRR(c.Context(), query)
+	})
+}
+
+// getChurnAnalytics handles subscription and revenue churn over time
+func (a *App) getChurnAnalytics(c *fiber.Ctx) error {
+	return analyticsReport(a, c, func(query *analytics.Query) ([]*analytics.Series[*analytics.ChurnBucket], error) {
+		return a.analytics.Churn(c.Context(), query)
+	})
+}
+
+// getDeclineReasons handles counting declined charges by failure code
+func (a *App) getDeclineReasons(c *fiber.Ctx) error {
+	if a.analytics == nil {
+		return a.errorMessage(c, fiber.StatusServiceUnavailable, "Analytics are not enabled", i18n.KeyGenericError)
+	}
+
+	query, err := analyticsQuery(c)
+	if err != nil {
+		return a.errorResponse(c, analyticsErrorStatus(err), err)
+	}
+
+	reasons, err := a.analytics.DeclineReasons(c.Context(), query)
+	if err != nil {
+		return a.errorResponse(c, analyticsErrorStatus(err), err)
+	}
+
+	var total int64
+	for _, reason := range reasons {
+		total += reason.Count
+	}
+
+	return c.JSON(fiber.Map{
+		"tenant_id": query.TenantID,
+		"from":      query.From,
+		"to":        query.To,
+		"currency":  query.Currency,
+		"declines":  total,
+		"reasons":   reasons,
+	})
+}
+
+// startAnalyticsIngestion joins the analytics consumer group on the events
+// topic when analytics are ingested from Kafka. Each batch counts as a
+// running job, and the consumer leaves its group from a shutdown hook once
+// running jobs finish.
+func (a *App) startAnalyticsIngestion() {
+	if a.analyticsIngester == nil {
+		return
+	}
+
+	config := *a.kafkaConfig
+	config.GroupID = a.analyticsConfig.ConsumerGroup
+	config.Topics = []string{a.kafkaConfig.EventsTopic}
+
+	handler := kafka.BatchHandlerFunc(func(ctx context.Context, messages []*kafka.Message) error {
+		done := a.drain.Begin(drain.KindJob)
+		defer done()
+		return a.analyticsIngester.HandleBatch(ctx, messages)
+	})
+
+	batches := batching.NewController(a.analyticsConfig.Batches)
+	consumer, err := kafka.NewBatchConsumer(&config, handler, batches, a.analyticsConfig.FlushInterval)
+	if err != nil {
+		log.Fatalf("Failed to start analytics consumer: %v", err)
+	}
+
+	consumer.Start()
+	a.drain.OnShutdown("analytics consumer", consumer.Close)
+	log.Printf("Ingesting analytics from %s as group %s", config.EventsTopic, config.GroupID)
+}
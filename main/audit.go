@@ -0,0 +1,171 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"apis/payments/services/audit"
+	"apis/payments/services/ephemeralkeys"
+	"apis/payments/services/i18n"
+	"apis/payments/services/requestid"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// unauditedRoutes are mutating routes that only read, so aren't audited
+var unauditedRoutes = map[string]bool{
+	"/graphql":                   true,
+	"/subscriptions/:id/preview": true,
+	"/routing/decisions":         true,
+}
+
+// auditErrorStatus maps audit errors to HTTP statuses
+func auditErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, audit.ErrEntryNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, audit.ErrInvalidCreatedRange):
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// auditCalls records every successful mutation on the API and admin ports
+// in the audit log once it has been served. Failing to record is logged
+// rather than failing a call that already took effect.
+func (a *App) auditCalls(c *fiber.Ctx) error {
+	if err := c.Next(); err != nil {
+		return err
+	}
+
+	status := c.Response().StatusCode()
+	if !audit.Recorded(c.Method(), status) {
+		return nil
+	}
+
+	// Client routes act on the ephemeral key's customer like their API
+	// counterparts
+	route := strings.TrimPrefix(c.Route().Path, apiPrefix)
+	route = strings.TrimPrefix(route, "/client")
+	if unauditedRoutes[route] {
+		return nil
+	}
+
+	actorType, actorID := a.auditActor(c)
+	call := &audit.Call{
+		Method:     c.Method(),
+		Route:      route,
+		Path:       c.OriginalURL(),
+		Params:     c.AllParams(),
+		Status:     status,
+		Response:   c.Response().Body(),
+		TenantID:   requestTenant(c),
+		ActorType:  actorType,
+		ActorID:    actorID,
+		OperatorID: c.Get("X-Operator-ID"),
+		IP:         c.IP(),
+		RequestID:  requestID(c),
+	}
+	if _, err := a.auditLog.Record(c.Context(), call); err != nil {
+		requestid.Logf(c.Context(), "Failed to audit %s %s: %v", call.Method, call.Path, err)
+	}
+
+	return nil
+}
+
+// auditActor identifies who made a call: the admin on the admin port, or
+// the authenticated principal, ephemeral key or API key on the API
+func (a *App) auditActor(c *fiber.Ctx) (string, string) {
+	if c.App() == a.adminApp {
+		return audit.ActorAdmin, ""
+	}
+	if principal := requestPrincipal(c); principal != nil {
+		return principal.Type, principal.ID
+	}
+	if key, ok := c.Locals(ephemeralKeyLocal).(*ephemeralkeys.Key); ok {
+		return audit.ActorEphemeralKey, key.ID
+	}
+	if apiKeyID := requestAPIKey(c); apiKeyID != "" {
+		return audit.ActorAPIKey, apiKeyID
+	}
+	return audit.ActorAnonymous, ""
+}
+
+// listAuditEntries handles listing audit entries, newest first. They can be
+// filtered by ?tenant_id=, ?actor_id=, ?action=, ?resource_type=,
+// ?resource_id=, ?created_after= and ?created_before= (RFC 3339).
+func (a *App) listAuditEntries(c *fiber.Ctx) error {
+	page, err := pageRequest(c)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	filter := audit.Filter{
+		TenantID:     c.Query("tenant_id"),
+		ActorID:      c.Query("actor_id"),
+		Action:       c.Query("action"),
+		ResourceType: c.Query("resource_type"),
+		ResourceID:   c.Query("resource_id"),
+		Limit:        int(page.Limit),
+	}
+	if page.StartingAfter != "" {
+		filter.BeforeSequence, err = strconv.ParseInt(page.StartingAfter, 10, 64)
+		if err != nil {
+			return a.errorMessage(c, fiber.StatusBadRequest, "cursor must be an audit entry sequence", i18n.KeyInvalidRequest)
+		}
+	}
+	for _, param := range []struct {
+		name string
+		dest **time.Time
+	}{
+		{"created_after", &filter.CreatedAfter},
+		{"created_before", &filter.CreatedBefore},
+	} {
+		if raw := c.Query(param.name); raw != "" {
+			parsed, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return a.errorMessage(c, fiber.StatusBadRequest, param.name+" must be an RFC 3339 timestamp", i18n.KeyInvalidRequest)
+			}
+			*param.dest = &parsed
+		}
+	}
+
+	entries, err := a.auditLog.List(c.Context(), filter)
+	if err != nil {
+		return a.errorResponse(c, auditErrorStatus(err), err)
+	}
+
+	entries, hasMore, nextCursor := trimPage(entries, page, func(entry *audit.Entry) string {
+		return strconv.FormatInt(entry.Sequence, 10)
+	})
+	return c.JSON(pageResponse(entries, hasMore, nextCursor))
+}
+
+// getAuditEntry handles retrieving an audit entry by its sequence
+func (a *App) getAuditEntry(c *fiber.Ctx) error {
+	sequence, err := strconv.ParseInt(c.Params("sequence"), 10, 64)
+	if err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "sequence must be an integer", i18n.KeyInvalidRequest)
+	}
+
+	entry, err := a.auditLog.Get(c.Context(), sequence)
+	if err != nil {
+		return a.errorResponse(c, auditErrorStatus(err), err)
+	}
+
+	return c.JSON(entry)
+}
+
+// verifyAuditLog handles checking the audit log's hash chain from its first
+// entry
+func (a *App) verifyAuditLog(c *fiber.Ctx) error {
+	verification, err := a.auditLog.Verify(c.Context())
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(verification)
+}
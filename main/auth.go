@@ -0,0 +1,180 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"apis/payments/services/auth"
+	"apis/payments/services/i18n"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// principalLocal is the fiber.Ctx local holding the authenticated principal
+const principalLocal = "principal"
+
+// authenticate requires an API key or bearer token granting the scope each
+// API route needs. Client routes authenticate with ephemeral keys instead,
+// and released quarantine mutations were authenticated when they were held.
+// Keys and tokens bound to a tenant can only act for that tenant.
+func (a *App) authenticate(c *fiber.Ctx) error {
+	if !a.auth.Enabled() || a.replayer.replaying(c) {
+		return c.Next()
+	}
+
+	path := strings.TrimPrefix(c.Path(), "/api/v1")
+	if path == "/client" || strings.HasPrefix(path, "/client/") {
+		return c.Next()
+	}
+
+	credential := strings.TrimPrefix(c.Get("Authorization"), "Bearer ")
+	principal, err := a.auth.Authenticate(c.Context(), credential)
+	if errors.Is(err, auth.ErrUnauthenticated) {
+		return a.errorMessage(c, fiber.StatusUnauthorized, err.Error(), i18n.KeyUnauthorized)
+	}
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+	if !principal.Allows(auth.RequiredScope(c.Method(), path)) {
+		return a.errorMessage(c, fiber.StatusForbidden, auth.ErrScopeNotAllowed.Error(), i18n.KeyNotPermitted)
+	}
+
+	if principal.TenantID != "" {
+		if tenantID := c.Get("X-Tenant-ID"); tenantID != "" && tenantID != principal.TenantID {
+			return a.errorMessage(c, fiber.StatusForbidden, auth.ErrScopeNotAllowed.Error(), i18n.KeyNotPermitted)
+		}
+		c.Request().Header.Set("X-Tenant-ID", principal.TenantID)
+	}
+
+	c.Locals(principalLocal, principal)
+	return c.Next()
+}
+
+// requestPrincipal returns the authenticated principal behind a request, or
+// nil when authentication is disabled or the request came through the admin
+// port
+func requestPrincipal(c *fiber.Ctx) *auth.Principal {
+	principal, _ := c.Locals(principalLocal).(*auth.Principal)
+	return principal
+}
+
+// apiKeyErrorStatus maps API key errors to HTTP statuses
+func apiKeyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, auth.ErrKeyNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, auth.ErrUnknownScope):
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// listAPIKeys returns every API key, without secrets
+func (a *App) listAPIKeys(c *fiber.Ctx) error {
+	keys, err := a.auth.ListKeys(c.Context())
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	// Tenant-bound callers only see their tenant's keys
+	if principal := requestPrincipal(c); principal != nil && principal.TenantID != "" {
+		visible := keys[:0]
+		for _, key := range keys {
+			if key.TenantID == principal.TenantID {
+				visible = append(visible, key)
+			}
+		}
+		keys = visible
+	}
+
+	return c.JSON(fiber.Map{"data": keys})
+}
+
+// createAPIKey creates an API key and returns its secret once. It is served
+// on the API for admin keys and on the admin port to bootstrap the first key.
+func (a *App) createAPIKey(c *fiber.Ctx) error {
+	var request auth.CreateKeyRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	request.CreatedBy = "admin"
+	if principal := requestPrincipal(c); principal != nil {
+		request.CreatedBy = principal.ID
+		// Tenant-bound callers can only create keys for their tenant
+		if principal.TenantID != "" {
+			request.TenantID = principal.TenantID
+		}
+	}
+
+	key, err := a.auth.CreateKey(c.Context(), &request)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(key)
+}
+
+// rotateAPIKey issues a replacement key. The old key keeps working for
+// grace_seconds, or the configured grace period when omitted.
+func (a *App) rotateAPIKey(c *fiber.Ctx) error {
+	var request struct {
+		GraceSeconds *int `json:"grace_seconds"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+		}
+	}
+
+	if err := a.visibleAPIKey(c, c.Params("id")); err != nil {
+		return a.errorResponse(c, apiKeyErrorStatus(err), err)
+	}
+
+	var grace *time.Duration
+	if request.GraceSeconds != nil {
+		duration := time.Duration(*request.GraceSeconds) * time.Second
+		grace = &duration
+	}
+
+	rotatedBy := "admin"
+	if principal := requestPrincipal(c); principal != nil {
+		rotatedBy = principal.ID
+	}
+
+	key, err := a.auth.RotateKey(c.Context(), c.Params("id"), grace, rotatedBy)
+	if err != nil {
+		return a.errorResponse(c, apiKeyErrorStatus(err), err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(key)
+}
+
+// revokeAPIKey invalidates an API key immediately
+func (a *App) revokeAPIKey(c *fiber.Ctx) error {
+	if err := a.visibleAPIKey(c, c.Params("id")); err != nil {
+		return a.errorResponse(c, apiKeyErrorStatus(err), err)
+	}
+
+	if err := a.auth.RevokeKey(c.Context(), c.Params("id")); err != nil {
+		return a.errorResponse(c, apiKeyErrorStatus(err), err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// visibleAPIKey checks that a key exists and, for tenant-bound callers,
+// belongs to their tenant. Keys of other tenants are reported as not found.
+func (a *App) visibleAPIKey(c *fiber.Ctx, id string) error {
+	key, err := a.auth.GetKey(c.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	if principal := requestPrincipal(c); principal != nil && principal.TenantID != "" && key.TenantID != principal.TenantID {
+		return auth.ErrKeyNotFound
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	"apis/payments/services"
+	"apis/payments/services/authorizations"
+	"apis/payments/services/i18n"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// authorizationResult is an authorization after a capture or void, with
+// the charge as the provider returned it
+type authorizationResult struct {
+	Authorization *authorizations.Authorization `json:"authorization"`
+	Charge        *services.Charge              `json:"charge"`
+}
+
+// authorizationErrorStatus maps authorization errors to HTTP statuses
+func authorizationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, authorizations.ErrAuthorizationNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, authorizations.ErrAuthorizationClosed), errors.Is(err, authorizations.ErrAuthorizationExpired):
+		return fiber.StatusConflict
+	case errors.Is(err, authorizations.ErrExceedsAuthorization), errors.Is(err, authorizations.ErrSingleCapture):
+		return fiber.StatusUnprocessableEntity
+	default:
+		return fiber.StatusBadRequest
+	}
+}
+
+// listAuthorizations handles listing the tenant's authorizations, optionally
+// in one status
+func (a *App) listAuthorizations(c *fiber.Ctx) error {
+	list, err := a.authorizations.List(c.Context(), authorizations.Filter{
+		TenantID: requestTenant(c),
+		Status:   c.Query("status"),
+		Limit:    c.QueryInt("limit"),
+	})
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(fiber.Map{"data": list})
+}
+
+// getAuthorization handles retrieving the authorization of a charge
+func (a *App) getAuthorization(c *fiber.Ctx) error {
+	chargeID := c.Params("id")
+	if chargeID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Charge ID is required", i18n.KeyMissingParameter)
+	}
+
+	authorization, err := a.authorizations.Get(c.Context(), requestTenant(c), chargeID)
+	if err != nil {
+		return a.errorResponse(c, authorizationErrorStatus(err), err)
+	}
+
+	return c.JSON(authorization)
+}
+
+// captureAuthorization handles capturing all or part of an authorization
+func (a *App) captureAuthorization(c *fiber.Ctx) error {
+	chargeID := c.Params("id")
+	if chargeID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Charge ID is required", i18n.KeyMissingParameter)
+	}
+
+	var request services.CaptureChargeRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+		}
+	}
+
+	authorization, charge, err := a.authorizations.Capture(c.Context(), requestTenant(c), chargeID, request)
+	if err != nil {
+		return a.errorResponse(c, authorizationErrorStatus(err), err)
+	}
+	a.chargeCache.Invalidate(c.Context(), chargeID)
+
+	return c.JSON(authorizationResult{Authorization: authorization, Charge: charge})
+}
+
+// voidAuthorization handles releasing an authorization without capturing it
+func (a *App) voidAuthorization(c *fiber.Ctx) error {
+	chargeID := c.Params("id")
+	if chargeID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Charge ID is required", i18n.KeyMissingParameter)
+	}
+
+	authorization, charge, err := a.authorizations.Void(c.Context(), requestTenant(c), chargeID)
+	if err != nil {
+		return a.errorResponse(c, authorizationErrorStatus(err), err)
+	}
+	a.chargeCache.Invalidate(c.Context(), chargeID)
+
+	return c.JSON(authorizationResult{Authorization: authorization, Charge: charge})
+}
+
+// sweepAuthorizations handles voiding stale authorizations immediately
+func (a *App) sweepAuthorizations(c *fiber.Ctx) error {
+	closed, err := a.authorizations.Sweep(c.Context(), time.Now())
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"authorizations_closed": closed,
+	})
+}
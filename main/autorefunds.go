@@ -0,0 +1,84 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"apis/payments/services/i18n"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// listAutoRefunds handles listing automatic refunds of unclaimed funds
+func (a *App) listAutoRefunds(c *fiber.Ctx) error {
+	refunds, err := a.autoRefunds.ListRefunds(c.Context(), c.Query("customer_id"), c.QueryInt("limit", 100))
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(refunds)
+}
+
+// listAutoRefundExclusions handles listing customers excluded from automatic refunds
+func (a *App) listAutoRefundExclusions(c *fiber.Ctx) error {
+	exclusions, err := a.autoRefunds.ListExclusions(c.Context())
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(exclusions)
+}
+
+// excludeFromAutoRefunds handles adding a customer to the exclusion list
+func (a *App) excludeFromAutoRefunds(c *fiber.Ctx) error {
+	customerID := c.Params("customerId")
+	if customerID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Customer ID is required", i18n.KeyMissingParameter)
+	}
+
+	var request struct {
+		Reason string `json:"reason"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+		}
+	}
+
+	exclusion, err := a.autoRefunds.Exclude(c.Context(), customerID, request.Reason)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	return c.JSON(exclusion)
+}
+
+// includeInAutoRefunds handles removing a customer from the exclusion list
+func (a *App) includeInAutoRefunds(c *fiber.Ctx) error {
+	customerID := c.Params("customerId")
+	if customerID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Customer ID is required", i18n.KeyMissingParameter)
+	}
+
+	if err := a.autoRefunds.Include(c.Context(), customerID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return a.errorResponse(c, fiber.StatusNotFound, err)
+		}
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// sweepAutoRefunds handles running the auto-refund sweep immediately
+func (a *App) sweepAutoRefunds(c *fiber.Ctx) error {
+	refunds, err := a.autoRefunds.Sweep(c.Context(), time.Now())
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"refunds": refunds,
+	})
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"apis/payments/services/blocklist"
+	"apis/payments/services/fingerprints"
+	"apis/payments/services/i18n"
+	"apis/payments/services/stripe"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// addBlocklistEntryRequest blocks an email or card fingerprint
+type addBlocklistEntryRequest struct {
+	Type   string `json:"type"`
+	Value  string `json:"value"`
+	Reason string `json:"reason"`
+}
+
+// cardFingerprintResponse is a card fingerprint's assessment and blocklist
+// status
+type cardFingerprintResponse struct {
+	*fingerprints.Assessment
+	Blocklisted bool `json:"blocklisted"`
+}
+
+// addValueListItemRequest adds a value to a Radar value list
+type addValueListItemRequest struct {
+	Value string `json:"value"`
+}
+
+// radarMirror keeps the blocklist in Radar value lists, one per entry type.
+// Lists are created on first use and looked up by alias after that.
+type radarMirror struct {
+	radar   *stripe.RadarService
+	aliases map[string]string
+
+	mu    sync.Mutex
+	lists map[string]string // entry type -> value list ID
+}
+
+// newRadarMirror creates a mirror using the configured value list aliases
+func newRadarMirror(radar *stripe.RadarService, aliases map[string]string) *radarMirror {
+	return &radarMirror{
+		radar:   radar,
+		aliases: aliases,
+		lists:   make(map[string]string),
+	}
+}
+
+// AddItem adds a value to the entry type's value list
+func (m *radarMirror) AddItem(ctx context.Context, entryType, value string) (string, error) {
+	listID, err := m.listID(ctx, entryType)
+	if err != nil {
+		return "", err
+	}
+
+	item, err := m.radar.AddValueListItem(ctx, listID, value)
+	if err != nil {
+		return "", err
+	}
+
+	return item.ID, nil
+}
+
+// RemoveItem removes a value list item
+func (m *radarMirror) RemoveItem(ctx context.Context, itemID string) error {
+	return m.radar.RemoveValueListItem(ctx, itemID)
+}
+
+// ListItems returns the values in the entry type's value list
+func (m *radarMirror) ListItems(ctx context.Context, entryType string) ([]*blocklist.MirrorItem, error) {
+	listID, err := m.listID(ctx, entryType)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := m.radar.ListValueListItems(ctx, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	mirrored := make([]*blocklist.MirrorItem, len(items))
+	for i, item := range items {
+		mirrored[i] = &blocklist.MirrorItem{ID: item.ID, Value: item.Value}
+	}
+
+	return mirrored, nil
+}
+
+// listID finds or creates the value list for an entry type. Radar item types
+// share our entry type names, so a list blocks values of the same kind.
+func (m *radarMirror) listID(ctx context.Context, entryType string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if listID, ok := m.lists[entryType]; ok {
+		return listID, nil
+	}
+
+	alias, ok := m.aliases[entryType]
+	if !ok {
+		return "", fmt.Errorf("no value list configured for %s entries", entryType)
+	}
+
+	list, err := m.radar.FindValueList(ctx, alias)
+	if err != nil {
+		return "", err
+	}
+	if list == nil {
+		list, err = m.radar.CreateValueList(ctx, &stripe.ValueListRequest{
+			Alias:    alias,
+			Name:     fmt.Sprintf("Blocked %s values", entryType),
+			ItemType: entryType,
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	m.lists[entryType] = list.ID
+	return list.ID, nil
+}
+
+// blocklistErrorStatus maps blocklist errors to HTTP statuses
+func blocklistErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, blocklist.ErrEntryNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, blocklist.ErrInvalidEntry):
+		return fiber.StatusUnprocessableEntity
+	case errors.Is(err, blocklist.ErrBlocked), errors.Is(err, fingerprints.ErrBlocked):
+		return fiber.StatusForbidden
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// listBlocklistEntries lists blocked values, optionally of one type
+func (a *App) listBlocklistEntries(c *fiber.Ctx) error {
+	entries, err := a.blocklist.List(c.Context(), c.Query("type"))
+	if err != nil {
+		return a.errorResponse(c, blocklistErrorStatus(err), err)
+	}
+
+	return c.JSON(entries)
+}
+
+// addBlocklistEntry blocks a value here and in the provider's value list
+func (a *App) addBlocklistEntry(c *fiber.Ctx) error {
+	var request addBlocklistEntryRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	entry, err := a.blocklist.Add(c.Context(), &blocklist.Entry{
+		Type:      request.Type,
+		Value:     request.Value,
+		Reason:    request.Reason,
+		CreatedBy: c.Get("X-Operator-ID"),
+	})
+	if err != nil {
+		return a.errorResponse(c, blocklistErrorStatus(err), err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(entry)
+}
+
+// removeBlocklistEntry unblocks a value here and in the provider's value list
+func (a *App) removeBlocklistEntry(c *fiber.Ctx) error {
+	entryID := c.Params("id")
+	if entryID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Blocklist entry ID is required", i18n.KeyMissingParameter)
+	}
+
+	if err := a.blocklist.Remove(c.Context(), entryID); err != nil {
+		return a.errorResponse(c, blocklistErrorStatus(err), err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// syncBlocklist reconciles the blocklist with the provider's value lists now
+func (a *App) syncBlocklist(c *fiber.Ctx) error {
+	result, err := a.blocklist.Sync(c.Context())
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadGateway, err)
+	}
+
+	return c.JSON(result)
+}
+
+// checkPaymentMethodBlocked detaches a newly added card whose fingerprint is
+// blocked, or that the fingerprint checks block, returning the block
+func (a *App) checkPaymentMethodBlocked(ctx context.Context, tenantID string, paymentMethod *stripe.PaymentMethod) error {
+	if paymentMethod.Card == nil {
+		return nil
+	}
+
+	blockedErr := a.blocklist.Check(ctx, blocklist.TypeCardFingerprint, paymentMethod.Card.Fingerprint)
+	if blockedErr == nil {
+		_, blockedErr = a.fingerprints.Check(ctx, tenantID, paymentMethod.Card.Fingerprint, paymentMethod.Customer)
+	}
+	if !errors.Is(blockedErr, blocklist.ErrBlocked) && !errors.Is(blockedErr, fingerprints.ErrBlocked) {
+		return blockedErr
+	}
+
+	if err := a.customerService.DetachPaymentMethod(ctx, paymentMethod.ID); err != nil {
+		return fmt.Errorf("failed to detach blocked payment method: %w", err)
+	}
+
+	return blockedErr
+}
+
+// getCardFingerprint handles assessing a card fingerprint for the tenant,
+// optionally for a ?customer_id=, and whether it is on the blocklist
+func (a *App) getCardFingerprint(c *fiber.Ctx) error {
+	fingerprint := c.Params("fingerprint")
+	if fingerprint == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Card fingerprint is required", i18n.KeyMissingParameter)
+	}
+
+	assessment, err := a.fingerprints.Assess(c.Context(), requestTenant(c), fingerprint, c.Query("customer_id"))
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	blockedErr := a.blocklist.Check(c.Context(), blocklist.TypeCardFingerprint, fingerprint)
+	if blockedErr != nil && !errors.Is(blockedErr, blocklist.ErrBlocked) {
+		return a.errorResponse(c, fiber.StatusInternalServerError, blockedErr)
+	}
+
+	return c.JSON(cardFingerprintResponse{Assessment: assessment, Blocklisted: blockedErr != nil})
+}
+
+// listValueLists lists the provider's Radar value lists
+func (a *App) listValueLists(c *fiber.Ctx) error {
+	lists, err := a.radar.ListValueLists(c.Context())
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadGateway, err)
+	}
+
+	return c.JSON(lists)
+}
+
+// createValueList creates a Radar value list
+func (a *App) createValueList(c *fiber.Ctx) error {
+	var request stripe.ValueListRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	list, err := a.radar.CreateValueList(c.Context(), &request)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(list)
+}
+
+// getValueList returns a Radar value list with its items
+func (a *App) getValueList(c *fiber.Ctx) error {
+	listID := c.Params("id")
+	if listID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Value list ID is required", i18n.KeyMissingParameter)
+	}
+
+	list, err := a.radar.GetValueList(c.Context(), listID)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusNotFound, err)
+	}
+
+	return c.JSON(list)
+}
+
+// deleteValueList deletes a Radar value list
+func (a *App) deleteValueList(c *fiber.Ctx) error {
+	listID := c.Params("id")
+	if listID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Value list ID is required", i18n.KeyMissingParameter)
+	}
+
+	if err := a.radar.DeleteValueList(c.Context(), listID); err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// addValueListItem adds a value to a Radar value list. Values added to the
+// lists the blocklist mirrors into are imported on the next sync.
+func (a *App) addValueListItem(c *fiber.Ctx) error {
+	listID := c.Params("id")
+	if listID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Value list ID is required", i18n.KeyMissingParameter)
+	}
+
+	var request addValueListItemRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	item, err := a.radar.AddValueListItem(c.Context(), listID, request.Value)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(item)
+}
+
+// removeValueListItem removes a value from a Radar value list
+func (a *App) removeValueListItem(c *fiber.Ctx) error {
+	itemID := c.Params("id")
+	if itemID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Value list item ID is required", i18n.KeyMissingParameter)
+	}
+
+	if err := a.radar.RemoveValueListItem(c.Context(), itemID); err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+
+	"apis/payments/services/budgets"
+	"apis/payments/services/i18n"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// updateBudgetRequest sets a tenant's monthly processing budget
+type updateBudgetRequest struct {
+	MonthlyLimit int64 `json:"monthly_limit"`
+	Thresholds   []int `json:"thresholds"`
+	HardStop     bool  `json:"hard_stop"`
+}
+
+// budgetErrorStatus maps budget errors to HTTP statuses
+func budgetErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, budgets.ErrBudgetNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, budgets.ErrBudgetExceeded):
+		return fiber.StatusForbidden
+	case errors.Is(err, budgets.ErrInvalidBudget), errors.Is(err, budgets.ErrNoExchangeRate):
+		return fiber.StatusUnprocessableEntity
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// getBudgetStatus returns the requesting tenant's spend this month against its budget
+func (a *App) getBudgetStatus(c *fiber.Ctx) error {
+	status, err := a.budgets.Status(c.Context(), requestTenant(c))
+	if err != nil {
+		return a.errorResponse(c, budgetErrorStatus(err), err)
+	}
+
+	return c.JSON(status)
+}
+
+// getTenantBudget returns a tenant's spend this month against its budget
+func (a *App) getTenantBudget(c *fiber.Ctx) error {
+	status, err := a.budgets.Status(c.Context(), c.Params("tenantId"))
+	if err != nil {
+		return a.errorResponse(c, budgetErrorStatus(err), err)
+	}
+
+	return c.JSON(status)
+}
+
+// updateTenantBudget sets a tenant's budget. Raising the limit lifts a hard
+// stop for the rest of the month.
+func (a *App) updateTenantBudget(c *fiber.Ctx) error {
+	var request updateBudgetRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	budget, err := a.budgets.Set(c.Context(), &budgets.Budget{
+		TenantID:     c.Params("tenantId"),
+		MonthlyLimit: request.MonthlyLimit,
+		Thresholds:   request.Thresholds,
+		HardStop:     request.HardStop,
+		UpdatedBy:    c.Get("X-Operator-ID"),
+	})
+	if err != nil {
+		return a.errorResponse(c, budgetErrorStatus(err), err)
+	}
+
+	return c.JSON(budget)
+}
+
+// deleteTenantBudget removes a tenant's budget
+func (a *App) deleteTenantBudget(c *fiber.Ctx) error {
+	if err := a.budgets.Delete(c.Context(), c.Params("tenantId")); err != nil {
+		return a.errorResponse(c, budgetErrorStatus(err), err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,12 @@
+package main
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// getCacheStats returns the hits and misses of cached provider reads
+func (a *App) getCacheStats(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"kinds": a.cacheMetrics.Snapshot(),
+	})
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"apis/payments/services/chargesearch"
+	"apis/payments/services/i18n"
+	"apis/payments/services/stripe"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// chargeSearchParams are the charge list filters served from the mirrored
+// charges rather than the provider
+var chargeSearchParams = []string{
+	"status", "currency", "min_amount", "max_amount", "card_brand", "card_last4", "created_after", "created_before",
+}
+
+// chargeSearchErrorStatus maps charge search errors to HTTP statuses
+func chargeSearchErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, chargesearch.ErrInvalidCreatedRange), errors.Is(err, chargesearch.ErrInvalidAmountRange),
+		errors.Is(err, chargesearch.ErrInvalidCardLast4), errors.Is(err, chargesearch.ErrUnsupportedFormat):
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// chargeSearchRequested reports whether a charge list request filters on
+// more than the customer
+func chargeSearchRequested(c *fiber.Ctx) bool {
+	for _, name := range chargeSearchParams {
+		if c.Query(name) != "" {
+			return true
+		}
+	}
+	for name := range c.Queries() {
+		if strings.HasPrefix(name, "metadata[") {
+			return true
+		}
+	}
+	return false
+}
+
+// chargeSearchFilter reads a charge search filter from a request's query
+func chargeSearchFilter(c *fiber.Ctx) (chargesearch.Filter, error) {
+	filter := chargesearch.Filter{
+		TenantID:   requestTenant(c),
+		Status:     c.Query("status"),
+		Currency:   c.Query("currency"),
+		CustomerID: c.Query("customer_id"),
+		CardBrand:  c.Query("card_brand"),
+		CardLast4:  c.Query("card_last4"),
+	}
+
+	for _, param := range []struct {
+		name string
+		dest **int64
+	}{
+		{"min_amount", &filter.MinAmount},
+		{"max_amount", &filter.MaxAmount},
+	} {
+		if raw := c.Query(param.name); raw != "" {
+			parsed, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil {
+				return filter, errors.New(param.name + " must be an integer amount")
+			}
+			*param.dest = &parsed
+		}
+	}
+	for _, param := range []struct {
+		name string
+		dest **time.Time
+	}{
+		{"created_after", &filter.CreatedAfter},
+		{"created_before", &filter.CreatedBefore},
+	} {
+		if raw := c.Query(param.name); raw != "" {
+			parsed, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return filter, errors.New(param.name + " must be an RFC 3339 timestamp")
+			}
+			*param.dest = &parsed
+		}
+	}
+	for name, value := range c.Queries() {
+		key, ok := strings.CutPrefix(name, "metadata[")
+		if !ok || !strings.HasSuffix(key, "]") {
+			continue
+		}
+		if filter.Metadata == nil {
+			filter.Metadata = map[string]string{}
+		}
+		filter.Metadata[strings.TrimSuffix(key, "]")] = value
+	}
+
+	return filter, nil
+}
+
+// searchCharges handles listing the tenant's mirrored charges matching the
+// request's filters, newest first
+func (a *App) searchCharges(c *fiber.Ctx) error {
+	page, err := pageRequest(c)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	filter, err := chargeSearchFilter(c)
+	if err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, err.Error(), i18n.KeyInvalidRequest)
+	}
+	filter.StartingAfter = page.StartingAfter
+	filter.Limit = int(page.Limit)
+
+	charges, err := a.chargeSearch.Search(c.Context(), filter)
+	if err != nil {
+		return a.errorResponse(c, chargeSearchErrorStatus(err), err)
+	}
+
+	charges, hasMore, nextCursor := trimPage(charges, page, func(charge *stripe.Charge) string { return charge.ID })
+	return c.JSON(pageResponse(charges, hasMore, nextCursor))
+}
+
+// exportCharges handles streaming every charge matching the request's
+// filters as a ?format=csv or ?format=json download. The status is sent
+// before the first row, so a failure part way through truncates the
+// download and is only logged.
+func (a *App) exportCharges(c *fiber.Ctx) error {
+	format := c.Query("format")
+	contentType, ok := chargesearch.ContentTypes[format]
+	if !ok {
+		return a.errorResponse(c, fiber.StatusBadRequest, chargesearch.ErrUnsupportedFormat)
+	}
+
+	filter, err := chargeSearchFilter(c)
+	if err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, err.Error(), i18n.KeyInvalidRequest)
+	}
+	if err := filter.Validate(); err != nil {
+		return a.errorResponse(c, chargeSearchErrorStatus(err), err)
+	}
+
+	ctx := c.Context()
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="charges.%s"`, format))
+	ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := a.chargeSearch.Export(ctx, filter, format, w); err != nil {
+			log.Printf("Charge export for tenant %s failed: %v", filter.TenantID, err)
+		}
+	})
+	return nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+
+	"apis/payments/services/chargestate"
+	"apis/payments/services/i18n"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// chargeStateErrorStatus maps charge state errors to HTTP statuses
+func chargeStateErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, chargestate.ErrIllegalTransition), errors.Is(err, chargestate.ErrConcurrentTransition):
+		return fiber.StatusConflict
+	case errors.Is(err, chargestate.ErrNoState):
+		return fiber.StatusNotFound
+	case errors.Is(err, chargestate.ErrUnknownState):
+		return fiber.StatusUnprocessableEntity
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// chargeStateRequest is an operator's correction of a charge's state
+type chargeStateRequest struct {
+	State string `json:"state"`
+}
+
+// getChargeTransitions returns a charge's current state, when it entered
+// each state and the transitions that led to it
+func (a *App) getChargeTransitions(c *fiber.Ctx) error {
+	chargeID := c.Params("id")
+	if chargeID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Charge ID is required", i18n.KeyMissingParameter)
+	}
+
+	transitions, err := a.chargeStates.History(c.Context(), chargeID)
+	if err != nil {
+		return a.errorResponse(c, chargeStateErrorStatus(err), err)
+	}
+	if len(transitions) == 0 {
+		return a.errorResponse(c, fiber.StatusNotFound, chargestate.ErrNoState)
+	}
+
+	return c.JSON(fiber.Map{
+		"state":       transitions[len(transitions)-1].To,
+		"entered_at":  chargestate.EnteredAt(transitions),
+		"transitions": transitions,
+	})
+}
+
+// setChargeState handles an operator moving a charge to a state. The state
+// machine applies as it does to webhooks, so illegal moves are rejected.
+func (a *App) setChargeState(c *fiber.Ctx) error {
+	chargeID := c.Params("id")
+	if chargeID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Charge ID is required", i18n.KeyMissingParameter)
+	}
+
+	var request chargeStateRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+	if request.State == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "State is required", i18n.KeyMissingParameter)
+	}
+
+	transition, err := a.chargeStates.Transition(c.Context(), chargeID, request.State, chargestate.SourceManual, "")
+	if err != nil {
+		return a.errorResponse(c, chargeStateErrorStatus(err), err)
+	}
+
+	return c.JSON(transition)
+}
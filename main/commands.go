@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"apis/payments/services/drain"
+	"apis/payments/services/kafka"
+)
+
+// startCommandConsumer joins the command consumer group when Kafka
+// consumption is enabled. Each command counts as a running job, and the
+// consumer leaves its group from a shutdown hook once running jobs finish;
+// commands not yet settled are redelivered to another worker.
+func (a *App) startCommandConsumer() {
+	if !a.kafkaConfig.Enabled {
+		return
+	}
+
+	handler := kafka.HandlerFunc(func(ctx context.Context, message *kafka.Message) error {
+		done := a.drain.Begin(drain.KindJob)
+		defer done()
+		return a.commands.Handle(ctx, message)
+	})
+
+	consumer, err := kafka.NewConsumer(a.kafkaConfig, handler)
+	if err != nil {
+		log.Fatalf("Failed to start command consumer: %v", err)
+	}
+
+	consumer.UseRecorder(commandDeadLetters{service: a.deadLetters})
+	consumer.Start()
+	a.drain.OnShutdown("command consumer", consumer.Close)
+	log.Printf("Consuming commands from %v as group %s", a.kafkaConfig.Topics, a.kafkaConfig.GroupID)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+
+	"apis/payments/services/composite"
+	"apis/payments/services/i18n"
+	"apis/payments/services/money"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// compositeErrorStatus maps composite charge errors to HTTP status codes
+func compositeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return fiber.StatusNotFound
+	case errors.Is(err, composite.ErrInvalidCompositeCharge),
+		errors.Is(err, composite.ErrInvalidRefund),
+		errors.Is(err, composite.ErrExceedsRefundable),
+		errors.Is(err, money.ErrInvalidDecimal),
+		errors.Is(err, money.ErrAmountMismatch):
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// createCompositeCharge handles grouping charges paid across several methods
+func (a *App) createCompositeCharge(c *fiber.Ctx) error {
+	var request composite.CreateChargeRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	charge, err := a.composite.CreateCharge(c.Context(), requestTenant(c), &request)
+	if err != nil {
+		return a.errorResponse(c, compositeErrorStatus(err), err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(charge)
+}
+
+// getCompositeCharge handles composite charge retrieval
+func (a *App) getCompositeCharge(c *fiber.Ctx) error {
+	charge, err := a.composite.GetCharge(c.Context(), c.Params("id"))
+	if err != nil {
+		return a.errorResponse(c, compositeErrorStatus(err), err)
+	}
+
+	return c.JSON(charge)
+}
+
+// refundCompositeCharge handles refunding a composite charge across its legs
+func (a *App) refundCompositeCharge(c *fiber.Ctx) error {
+	var request composite.RefundRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	refund, err := a.composite.Refund(c.Context(), refundActor(c), c.Params("id"), &request)
+	if err != nil {
+		return a.errorResponse(c, compositeErrorStatus(err), err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(refund)
+}
+
+// listCompositeRefunds handles listing the refunds of a composite charge
+func (a *App) listCompositeRefunds(c *fiber.Ctx) error {
+	refunds, err := a.composite.ListRefunds(c.Context(), c.Params("id"))
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(refunds)
+}
+
+// getCompositeRefund handles composite refund retrieval
+func (a *App) getCompositeRefund(c *fiber.Ctx) error {
+	refund, err := a.composite.GetRefund(c.Context(), c.Params("id"))
+	if err != nil {
+		return a.errorResponse(c, compositeErrorStatus(err), err)
+	}
+
+	return c.JSON(refund)
+}
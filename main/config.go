@@ -0,0 +1,191 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"apis/payments/services/batching"
+	"apis/payments/services/config"
+	"apis/payments/services/ratelimit"
+	"apis/payments/services/routing"
+	"apis/payments/services/runmode"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// settings are the validated settings. Every other variable can be set in
+// the config file too, but is only checked by the service reading it.
+var settings = append([]config.Setting{
+	{Name: "PORT", Kind: config.KindInt},
+	{Name: "ADMIN_PORT", Kind: config.KindInt},
+	{Name: "ADMIN_TOKEN", Kind: config.KindString, Secret: true},
+	{Name: "RUN_MODE", Kind: config.KindString, Check: func(value string) error {
+		_, err := runmode.Parse(value)
+		return err
+	}},
+	{Name: "PAYMENT_PROVIDER", Kind: config.KindString},
+	{Name: "STRIPE_SECRET_KEY", Kind: config.KindString, Secret: true},
+	{Name: "STRIPE_PUBLISHABLE_KEY", Kind: config.KindString},
+	{Name: "STRIPE_WEBHOOK_SECRET", Kind: config.KindString, Secret: true},
+	{Name: "PADDLE_API_KEY", Kind: config.KindString, Secret: true},
+	{Name: "PADDLE_WEBHOOK_SECRET", Kind: config.KindString, Secret: true},
+	{Name: "SQUARE_ACCESS_TOKEN", Kind: config.KindString, Secret: true},
+	{Name: "PAYPAL_CLIENT_SECRET", Kind: config.KindString, Secret: true},
+	{Name: "PAYMENT_SECRET_KEY", Kind: config.KindString, Secret: true},
+	{Name: "YB_HOST", Kind: config.KindString},
+	{Name: "YB_PORT", Kind: config.KindInt},
+	{Name: "YB_USER", Kind: config.KindString},
+	{Name: "YB_PASSWORD", Kind: config.KindString, Secret: true},
+	{Name: "YB_DBNAME", Kind: config.KindString},
+	{Name: "YB_MAX_CONNECTIONS", Kind: config.KindInt},
+	{Name: "YB_MIN_CONNECTIONS", Kind: config.KindInt},
+	{Name: "CH_PASSWORD", Kind: config.KindString, Secret: true},
+	{Name: "REDIS_URL", Kind: config.KindURL, Secret: true},
+	{Name: "REDIS_TIMEOUT_MS", Kind: config.KindInt},
+	{Name: "VAULT_TOKEN", Kind: config.KindString, Secret: true},
+	{Name: "CACHE_ENABLED", Kind: config.KindBool},
+	{Name: "CACHE_CUSTOMER_TTL_SECONDS", Kind: config.KindInt},
+	{Name: "CACHE_CHARGE_TTL_SECONDS", Kind: config.KindInt},
+	{Name: "JOBS_MAX_ATTEMPTS", Kind: config.KindInt},
+	{Name: "JOBS_RETRY_BACKOFF_SECONDS", Kind: config.KindInt},
+	{Name: "AUTHORIZATION_VOID_MARGIN_HOURS", Kind: config.KindInt},
+	{Name: "AUTHORIZATION_SWEEP_INTERVAL_MINUTES", Kind: config.KindInt},
+	{Name: "AUTHORIZATION_SWEEP_BATCH_SIZE", Kind: config.KindInt},
+	{Name: "REFUND_VELOCITY_WINDOW_MINUTES", Kind: config.KindInt},
+	{Name: "REFUND_VELOCITY_BASELINE_HOURS", Kind: config.KindInt},
+	{Name: "REFUND_VELOCITY_MULTIPLIER", Kind: config.KindFloat},
+	{Name: "REFUND_VELOCITY_MIN_COUNT", Kind: config.KindInt},
+	{Name: "REFUND_VELOCITY_MIN_AMOUNT", Kind: config.KindInt},
+	{Name: "REFUND_VELOCITY_HARD_COUNT", Kind: config.KindInt},
+	{Name: "REFUND_VELOCITY_HARD_AMOUNT", Kind: config.KindInt},
+	{Name: "REFUND_POLICY_MAX_PERCENT", Kind: config.KindFloat},
+	{Name: "REFUND_POLICY_WINDOW_DAYS", Kind: config.KindInt},
+	{Name: "REFUND_APPROVAL_THRESHOLD", Kind: config.KindInt},
+	{Name: "CARD_FINGERPRINT_SHARED_CUSTOMERS_FLAG", Kind: config.KindInt},
+	{Name: "CARD_FINGERPRINT_SHARED_CUSTOMERS_BLOCK", Kind: config.KindInt},
+	{Name: "CARD_FINGERPRINT_CHARGEBACKS_FLAG", Kind: config.KindInt},
+	{Name: "CARD_FINGERPRINT_CHARGEBACKS_BLOCK", Kind: config.KindInt},
+	{Name: "CARD_FINGERPRINT_CHARGEBACK_LOOKBACK_DAYS", Kind: config.KindInt},
+	{Name: "GRAPHQL_MAX_DEPTH", Kind: config.KindInt},
+	{Name: "GRAPHQL_LOADER_WAIT_MS", Kind: config.KindInt},
+	{Name: "GRAPHQL_LOADER_CONCURRENCY", Kind: config.KindInt},
+	{Name: "SMART_ROUTING_MIN_SAMPLES", Kind: config.KindInt},
+	{Name: "SMART_ROUTING_WINDOW_HOURS", Kind: config.KindInt},
+	{Name: "SMART_ROUTING_REFRESH_SECONDS", Kind: config.KindInt},
+	{Name: "DISPUTE_EVIDENCE_REMINDER_HOURS", Kind: config.KindInt},
+	{Name: "DISPUTE_EVIDENCE_REMINDER_INTERVAL_MINUTES", Kind: config.KindInt},
+	{Name: "DISPUTE_EVIDENCE_MAX_FILE_MB", Kind: config.KindInt},
+	{Name: "REFUND_BATCH_MAX_SIZE", Kind: config.KindInt},
+	{Name: "REFUND_BATCH_CONCURRENCY", Kind: config.KindInt},
+	{Name: "REFUND_BATCH_INTERVAL_SECONDS", Kind: config.KindInt},
+	{Name: "REFUND_BATCH_RUN_SIZE", Kind: config.KindInt},
+	{Name: "USAGE_REPORT_INTERVAL_MINUTES", Kind: config.KindInt},
+	{Name: "USAGE_REPORT_MAX_ATTEMPTS", Kind: config.KindInt},
+	{Name: "USAGE_REPORT_BATCH_SIZE", Kind: config.KindInt},
+	{Name: "FRAUD_VELOCITY_WINDOW_MINUTES", Kind: config.KindInt},
+	{Name: "FRAUD_VELOCITY_REVIEW", Kind: config.KindInt},
+	{Name: "FRAUD_VELOCITY_REJECT", Kind: config.KindInt},
+	{Name: "FRAUD_AMOUNT_BASELINE_DAYS", Kind: config.KindInt},
+	{Name: "FRAUD_AMOUNT_MIN_HISTORY", Kind: config.KindInt},
+	{Name: "FRAUD_AMOUNT_MULTIPLIER", Kind: config.KindFloat},
+	{Name: "FRAUD_RADAR_REVIEW_SCORE", Kind: config.KindInt},
+	{Name: "FRAUD_RADAR_REJECT_SCORE", Kind: config.KindInt},
+	{Name: "FRAUD_RADAR_LOOKBACK_DAYS", Kind: config.KindInt},
+	{Name: "DUNNING_GRACE_DAYS", Kind: config.KindInt},
+	{Name: "DUNNING_SUSPEND_DAYS", Kind: config.KindInt},
+	{Name: "DUNNING_CANCEL_DAYS", Kind: config.KindInt},
+	{Name: "DUNNING_INTERVAL_MINUTES", Kind: config.KindInt},
+	{Name: "DUNNING_BATCH_SIZE", Kind: config.KindInt},
+	{Name: "WEBHOOK_MAX_IN_FLIGHT", Kind: config.KindInt},
+	{Name: "WEBHOOK_MAX_DB_LATENCY_MS", Kind: config.KindInt},
+	{Name: "WEBHOOK_DB_PROBE_INTERVAL_MS", Kind: config.KindInt},
+	{Name: "WEBHOOK_RETRY_AFTER_MIN_SECONDS", Kind: config.KindInt},
+	{Name: "WEBHOOK_RETRY_AFTER_MAX_SECONDS", Kind: config.KindInt},
+	{Name: "SHUTDOWN_PRESTOP_DELAY_SECONDS", Kind: config.KindInt},
+	{Name: "SHUTDOWN_GRACE_PERIOD_SECONDS", Kind: config.KindInt},
+	{Name: "GATEWAY_RETRY_MAX_ATTEMPTS", Kind: config.KindInt},
+	{Name: "GATEWAY_RETRY_BASE_DELAY_MS", Kind: config.KindInt},
+	{Name: "GATEWAY_RETRY_MAX_DELAY_MS", Kind: config.KindInt},
+	{Name: "GATEWAY_ATTEMPT_TIMEOUT_MS", Kind: config.KindInt},
+	{Name: "GATEWAY_CALL_BUDGET_MS", Kind: config.KindInt},
+	{Name: "GATEWAY_BREAKER_FAILURE_THRESHOLD", Kind: config.KindInt},
+	{Name: "GATEWAY_BREAKER_OPEN_SECONDS", Kind: config.KindInt},
+	{Name: "ANALYTICS_FLUSH_INTERVAL_MS", Kind: config.KindInt},
+	{Name: "ANALYTICS_QUEUE_SIZE", Kind: config.KindInt},
+	{Name: "RELAY_OUTBOX_ENABLED", Kind: config.KindBool},
+	{Name: "RELAY_OUTBOX_INTERVAL_MS", Kind: config.KindInt},
+	{Name: "RATE_LIMIT_BACKEND", Kind: config.KindString},
+	{Name: "RATE_LIMIT_ENABLED", Kind: config.KindBool, Reloadable: true},
+	{Name: "RATE_LIMIT_RATE", Kind: config.KindFloat, Reloadable: true},
+	{Name: "RATE_LIMIT_BURST", Kind: config.KindInt, Reloadable: true},
+	{Name: "RATE_LIMIT_ENDPOINTS", Kind: config.KindString, Reloadable: true, Check: func(value string) error {
+		_, err := ratelimit.ParseRules(value)
+		return err
+	}},
+	{Name: "ROUTING_DEFAULT_PROVIDER", Kind: config.KindString, Reloadable: true},
+	{Name: "ROUTING_CURRENCY_ROUTES", Kind: config.KindString, Reloadable: true},
+	{Name: "ROUTING_FAILOVER_RULES", Kind: config.KindString, Reloadable: true, Check: func(value string) error {
+		_, err := routing.ParseFailoverRules(value)
+		return err
+	}},
+	{Name: "PROVIDER_SETTLEMENT_CURRENCIES", Kind: config.KindString, Reloadable: true},
+	{Name: "REPORTING_BASE_CURRENCY", Kind: config.KindString, Reloadable: true},
+	{Name: "REPORTING_FX_RATES", Kind: config.KindString, Reloadable: true},
+}, batchSettings("PROJECTION_REBUILD", "ANALYTICS", "EVENT_REPLAY", "RELAY_OUTBOX")...)
+
+// batchSettings returns the limits of the adaptive batches named with each
+// prefix
+func batchSettings(prefixes ...string) []config.Setting {
+	var settings []config.Setting
+	for _, prefix := range prefixes {
+		settings = append(settings, batching.Settings(prefix)...)
+	}
+	return settings
+}
+
+// applyConfig applies reloaded rate limits and routing
+func (a *App) applyConfig(changed []string) {
+	rateLimits, routes := false, false
+	for _, name := range changed {
+		if strings.HasPrefix(name, "RATE_LIMIT_") {
+			rateLimits = true
+		} else {
+			routes = true
+		}
+	}
+
+	if rateLimits {
+		rateLimitConfig, err := ratelimit.LoadConfig()
+		if err != nil {
+			log.Printf("Failed to reload rate limits: %v", err)
+		} else {
+			a.rateLimits.Reconfigure(rateLimitConfig)
+		}
+	}
+	if routes {
+		a.router.Reconfigure(routing.LoadConfig())
+	}
+}
+
+// getConfig returns where each validated setting's value came from, with
+// the values of settings that are not secret
+func (a *App) getConfig(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"data": a.config.Statuses()})
+}
+
+// reloadConfig handles reloading the config file, as SIGHUP does
+func (a *App) reloadConfig(c *fiber.Ctx) error {
+	changed, err := a.config.Reload()
+	if errors.Is(err, config.ErrInvalid) {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	if changed == nil {
+		changed = []string{}
+	}
+	return c.JSON(fiber.Map{"changed": changed})
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"apis/payments/services/i18n"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// getCredentialStats handles charge volume and approval rates by card
+// credential type for the caller's tenant
+func (a *App) getCredentialStats(c *fiber.Ctx) error {
+	currency := strings.ToLower(c.Query("currency"))
+	if currency == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Currency is required", i18n.KeyMissingParameter)
+	}
+
+	days := c.QueryInt("days", 30)
+	if days <= 0 {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Days must be positive", i18n.KeyInvalidRequest)
+	}
+
+	tenantID := requestTenant(c)
+	since := time.Now().AddDate(0, 0, -days)
+
+	stats, err := a.repository.GetChargeCredentialStats(c.Context(), tenantID, currency, since)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"tenant_id": tenantID,
+		"currency":  currency,
+		"since":     since.UTC(),
+		"stats":     stats,
+	})
+}
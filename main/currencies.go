@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+
+	"apis/payments/services/fx"
+	"apis/payments/services/i18n"
+	"apis/payments/services/money"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// convertCurrency handles converting an amount, in minor units or as a
+// decimal, to another currency at the latest exchange rate
+func (a *App) convertCurrency(c *fiber.Ctx) error {
+	from, to := c.Query("from"), c.Query("to")
+	if from == "" || to == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "from and to currencies are required", i18n.KeyMissingParameter)
+	}
+	if c.Query("amount") == "" && c.Query("amount_decimal") == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "amount or amount_decimal is required", i18n.KeyMissingParameter)
+	}
+
+	amount, err := money.ResolveAmount(int64(c.QueryInt("amount")), c.Query("amount_decimal"), from)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	conversion, err := a.fx.Convert(c.Context(), money.New(amount, from), to)
+	if err != nil {
+		status := fiber.StatusBadRequest
+		if errors.Is(err, fx.ErrRatesUnavailable) {
+			status = fiber.StatusServiceUnavailable
+		}
+		return a.errorResponse(c, status, err)
+	}
+
+	return c.JSON(conversion)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"apis/payments/services/customerbalance"
+	"apis/payments/services/i18n"
+	"apis/payments/services/requestid"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// customerBalanceErrorStatus maps customer balance errors to HTTP status codes
+func customerBalanceErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, customerbalance.ErrInvalidCredit), errors.Is(err, customerbalance.ErrRefundExceedsCharge):
+		return fiber.StatusUnprocessableEntity
+	case errors.Is(err, customerbalance.ErrInsufficientBalance):
+		return fiber.StatusConflict
+	default:
+		return fiber.StatusBadRequest
+	}
+}
+
+// getCustomerBalance handles retrieving a customer's credit balances with
+// their latest transactions (?limit=, up to 100)
+func (a *App) getCustomerBalance(c *fiber.Ctx) error {
+	summary, err := a.customerBalances.Summary(c.Context(), requestTenant(c), c.Params("id"), c.QueryInt("limit", 0))
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(summary)
+}
+
+// creditCustomerBalance handles issuing a credit to a customer, such as a
+// goodwill credit or a charge refunded to the balance
+func (a *App) creditCustomerBalance(c *fiber.Ctx) error {
+	var request customerbalance.CreditRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+	request.CreatedBy = c.Get("X-Operator-ID")
+
+	transaction, err := a.customerBalances.Credit(c.Context(), requestTenant(c), c.Params("id"), &request)
+	if err != nil {
+		return a.errorResponse(c, customerBalanceErrorStatus(err), err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(transaction)
+}
+
+// reconcileCustomerBalance handles matching a customer's balance to their
+// Stripe customer balance
+func (a *App) reconcileCustomerBalance(c *fiber.Ctx) error {
+	reconciliation, err := a.customerBalances.Reconcile(c.Context(), requestTenant(c), c.Params("id"))
+	if err != nil {
+		return a.errorResponse(c, customerBalanceErrorStatus(err), err)
+	}
+
+	return c.JSON(reconciliation)
+}
+
+// reverseCustomerBalance restores the balance applied to a charge that was
+// declined or held for review
+func (a *App) reverseCustomerBalance(ctx context.Context, application *customerbalance.Application) {
+	if application == nil {
+		return
+	}
+	if _, err := a.customerBalances.Reverse(ctx, application); err != nil {
+		requestid.Logf(ctx, "Failed to reverse balance transaction %s: %v", application.Transaction.ID, err)
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"errors"
+
+	"apis/payments/services/blocklist"
+	"apis/payments/services/customers"
+	"apis/payments/services/i18n"
+	"apis/payments/services/metadata"
+	"apis/payments/services/stripe"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// verifyEmailRequest confirms a customer's email with the token sent to it
+type verifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+// upsertCustomerRequest creates or updates the customer with an external reference
+type upsertCustomerRequest struct {
+	stripe.CustomerRequest
+	ExternalReference string `json:"external_reference"`
+}
+
+// customerIdentityErrorStatus maps customer identity errors to HTTP statuses
+func customerIdentityErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, customers.ErrIdentityNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, customers.ErrDuplicateCustomer), errors.Is(err, customers.ErrAlreadyVerified):
+		return fiber.StatusConflict
+	case errors.Is(err, customers.ErrInvalidVerificationToken):
+		return fiber.StatusUnprocessableEntity
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// duplicateCustomerResponse rejects a customer creation that duplicates an
+// existing customer, referencing it so the caller can use it instead
+func (a *App) duplicateCustomerResponse(c *fiber.Ctx, duplicate *customers.DuplicateError) error {
+	return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+		"error":                duplicate.Error(),
+		"request_id":           requestID(c),
+		"display_message":      a.translator.Localize(duplicate, c.Get("Accept-Language")),
+		"existing_customer_id": duplicate.Existing.CustomerID,
+		"name_similarity":      duplicate.Similarity,
+	})
+}
+
+// getEmailVerification returns whether a customer's email is verified
+func (a *App) getEmailVerification(c *fiber.Ctx) error {
+	identity, err := a.customerIdentities.Get(c.Context(), c.Params("id"))
+	if err != nil {
+		return a.errorResponse(c, customerIdentityErrorStatus(err), err)
+	}
+
+	return c.JSON(identity)
+}
+
+// requestEmailVerification issues a new verification token for a customer's
+// email. The token is also emitted for delivery by a notifier.
+func (a *App) requestEmailVerification(c *fiber.Ctx) error {
+	customerID := c.Params("id")
+
+	customer, err := a.customerService.GetCustomer(c.Context(), customerID)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusNotFound, err)
+	}
+
+	verification, err := a.customerIdentities.RequestVerification(c.Context(), customerID, customer.Email)
+	if err != nil {
+		return a.errorResponse(c, customerIdentityErrorStatus(err), err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(verification)
+}
+
+// verifyCustomerEmail confirms a customer's email with its verification token
+func (a *App) verifyCustomerEmail(c *fiber.Ctx) error {
+	var request verifyEmailRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+	if request.Token == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Token is required", i18n.KeyMissingParameter)
+	}
+
+	identity, err := a.customerIdentities.VerifyEmail(c.Context(), c.Params("id"), request.Token)
+	if err != nil {
+		return a.errorResponse(c, customerIdentityErrorStatus(err), err)
+	}
+
+	return c.JSON(identity)
+}
+
+// upsertCustomer creates or updates the tenant's customer keyed by the
+// caller's external reference, answering 201 when it was created
+func (a *App) upsertCustomer(c *fiber.Ctx) error {
+	var request upsertCustomerRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	if err := a.checkMetadata(c, metadata.ResourceCustomer, request.Metadata); err != nil {
+		return a.errorResponse(c, metadataErrorStatus(err), err)
+	}
+
+	if err := a.blocklist.Check(c.Context(), blocklist.TypeEmail, request.Email); err != nil {
+		return a.errorResponse(c, blocklistErrorStatus(err), err)
+	}
+
+	customer, created, err := a.customerUpserts.Upsert(c.Context(), requestTenant(c), request.ExternalReference, &request.CustomerRequest)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	if created {
+		return c.Status(fiber.StatusCreated).JSON(customer)
+	}
+	a.customerCache.Invalidate(c.Context(), customer.ID)
+	return c.JSON(customer)
+}
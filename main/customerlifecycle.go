@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+
+	"apis/payments/services/customers"
+	"apis/payments/services/i18n"
+	"apis/payments/services/requestid"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// customerLifecycleErrorStatus maps customer deletion and erasure errors to
+// HTTP statuses
+func customerLifecycleErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, customers.ErrCustomerDeleted):
+		return fiber.StatusNotFound
+	case errors.Is(err, customers.ErrCustomerErased):
+		return fiber.StatusGone
+	case errors.Is(err, customers.ErrNotRestorable):
+		return fiber.StatusConflict
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// restoreCustomer handles undeleting a customer within its retention period
+func (a *App) restoreCustomer(c *fiber.Ctx) error {
+	customerID := c.Params("id")
+	if customerID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Customer ID is required", i18n.KeyMissingParameter)
+	}
+
+	if err := a.customerLifecycle.Restore(c.Context(), customerID); err != nil {
+		return a.errorResponse(c, customerLifecycleErrorStatus(err), err)
+	}
+	a.customerCache.Invalidate(c.Context(), customerID)
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// eraseCustomer handles erasing a customer's personal data on request,
+// revoking the vault tokens of its detached payment methods
+func (a *App) eraseCustomer(c *fiber.Ctx) error {
+	customerID := c.Params("id")
+	if customerID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Customer ID is required", i18n.KeyMissingParameter)
+	}
+
+	erasure, err := a.customerLifecycle.Erase(c.Context(), requestTenant(c), customerID)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+	a.customerCache.Invalidate(c.Context(), customerID)
+
+	tokens, err := a.vault.ListForCustomer(c.Context(), customerID)
+	if err != nil {
+		requestid.Logf(c.Context(), "Failed to list vault tokens for erased customer %s: %v", customerID, err)
+	}
+	for _, token := range tokens {
+		if err := a.vault.Revoke(c.Context(), token.ID); err != nil {
+			requestid.Logf(c.Context(), "Failed to revoke vault token %s of erased customer %s: %v", token.ID, customerID, err)
+		}
+	}
+
+	return c.JSON(erasure)
+}
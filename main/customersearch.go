@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"apis/payments/services/customers"
+	"apis/payments/services/i18n"
+	"apis/payments/services/stripe"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// customerSearchErrorStatus maps customer search errors to HTTP statuses
+func customerSearchErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, customers.ErrInvalidCreatedRange), errors.Is(err, customers.ErrSearchQueryRequired):
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// listCustomers handles listing the tenant's customers, newest first. They
+// can be filtered by ?email=, ?name_prefix=, ?created_after= and
+// ?created_before= (RFC 3339), and by metadata with ?metadata[key]=value.
+func (a *App) listCustomers(c *fiber.Ctx) error {
+	page, err := pageRequest(c)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	filter := customers.Filter{
+		TenantID:      requestTenant(c),
+		Email:         c.Query("email"),
+		NamePrefix:    c.Query("name_prefix"),
+		StartingAfter: page.StartingAfter,
+		Limit:         int(page.Limit),
+	}
+	for _, param := range []struct {
+		name string
+		dest **time.Time
+	}{
+		{"created_after", &filter.CreatedAfter},
+		{"created_before", &filter.CreatedBefore},
+	} {
+		if raw := c.Query(param.name); raw != "" {
+			parsed, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return a.errorMessage(c, fiber.StatusBadRequest, param.name+" must be an RFC 3339 timestamp", i18n.KeyInvalidRequest)
+			}
+			*param.dest = &parsed
+		}
+	}
+	for name, value := range c.Queries() {
+		key, ok := strings.CutPrefix(name, "metadata[")
+		if !ok || !strings.HasSuffix(key, "]") {
+			continue
+		}
+		if filter.Metadata == nil {
+			filter.Metadata = map[string]string{}
+		}
+		filter.Metadata[strings.TrimSuffix(key, "]")] = value
+	}
+
+	found, err := a.customerSearch.List(c.Context(), filter)
+	if err != nil {
+		return a.errorResponse(c, customerSearchErrorStatus(err), err)
+	}
+
+	found, hasMore, nextCursor := trimPage(found, page, func(customer *stripe.Customer) string { return customer.ID })
+	return c.JSON(pageResponse(found, hasMore, nextCursor))
+}
+
+// searchCustomers handles typeahead search of the tenant's customers by ?q=,
+// matching an ID exactly or the start of an email or name
+func (a *App) searchCustomers(c *fiber.Ctx) error {
+	found, err := a.customerSearch.Search(c.Context(), requestTenant(c), c.Query("q"), c.QueryInt("limit"))
+	if err != nil {
+		return a.errorResponse(c, customerSearchErrorStatus(err), err)
+	}
+
+	return c.JSON(fiber.Map{"data": found})
+}
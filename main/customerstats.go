@@ -0,0 +1,23 @@
+package main
+
+import (
+	"errors"
+
+	"apis/payments/services/customerstats"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// getCustomerStats returns a customer's charge statistics, over their
+// lifetime or the last ?days= days
+func (a *App) getCustomerStats(c *fiber.Ctx) error {
+	stats, err := a.customerStats.Get(c.Context(), c.Params("id"), c.QueryInt("days", 0))
+	if errors.Is(err, customerstats.ErrInvalidWindow) {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(stats)
+}
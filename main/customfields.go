@@ -0,0 +1,85 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+
+	"apis/payments/services/customfields"
+	"apis/payments/services/i18n"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// collectCustomFields validates custom field values against the tenant's
+// fields and adds them to the resource's metadata, returning the merged
+// metadata and the values rendered with their labels
+func (a *App) collectCustomFields(c *fiber.Ctx, metadata, values map[string]string) (map[string]string, []customfields.Value, error) {
+	tenantID := requestTenant(c)
+
+	rendered, err := a.customFields.Collect(c.Context(), tenantID, values)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(values) == 0 {
+		return metadata, nil, nil
+	}
+
+	// The tenant is recorded so the values can be labelled again later
+	merged := customfields.ToMetadata(metadata, values)
+	if merged[customfields.TenantMetadataKey] == "" {
+		merged[customfields.TenantMetadataKey] = tenantID
+	}
+
+	return merged, rendered, nil
+}
+
+// customFieldsErrorStatus maps custom field errors to HTTP status codes
+func customFieldsErrorStatus(err error) int {
+	if errors.Is(err, customfields.ErrInvalidCustomFields) || errors.Is(err, customfields.ErrInvalidDefinition) {
+		return fiber.StatusBadRequest
+	}
+	return fiber.StatusInternalServerError
+}
+
+// getCustomFields handles retrieving the tenant's custom fields
+func (a *App) getCustomFields(c *fiber.Ctx) error {
+	definition, err := a.customFields.GetDefinition(c.Context(), requestTenant(c))
+	if errors.Is(err, sql.ErrNoRows) {
+		return a.errorMessage(c, fiber.StatusNotFound, "Custom fields not found", i18n.KeyNotFound)
+	}
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(definition)
+}
+
+// defineCustomFields handles defining or replacing the tenant's custom fields
+func (a *App) defineCustomFields(c *fiber.Ctx) error {
+	var request struct {
+		Fields []*customfields.Field `json:"fields"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	definition, err := a.customFields.Define(c.Context(), requestTenant(c), request.Fields)
+	if err != nil {
+		return a.errorResponse(c, customFieldsErrorStatus(err), err)
+	}
+
+	return c.JSON(definition)
+}
+
+// deleteCustomFields handles removing the tenant's custom fields
+func (a *App) deleteCustomFields(c *fiber.Ctx) error {
+	deleted, err := a.customFields.DeleteDefinition(c.Context(), requestTenant(c))
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+	if !deleted {
+		return a.errorMessage(c, fiber.StatusNotFound, "Custom fields not found", i18n.KeyNotFound)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
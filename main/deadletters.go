@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"apis/payments/services/deadletter"
+	"apis/payments/services/drain"
+	"apis/payments/services/i18n"
+	"apis/payments/services/kafka"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// registerDeadLetterReplayers lets the dead-letter queue replay each source
+// through the same path that failed
+func (a *App) registerDeadLetterReplayers() {
+	// Webhook events are deduplicated, so replaying one Stripe has since
+	// redelivered successfully resolves it without dispatching it again
+	a.deadLetters.Register(deadletter.SourceStripeWebhook, deadletter.ReplayerFunc(func(ctx context.Context, entry *deadletter.Entry) error {
+		_, err := a.webhookService.Replay(ctx, entry.Payload)
+		return err
+	}))
+
+	// Commands carry their own idempotency keys
+	a.deadLetters.Register(deadletter.SourceKafkaCommand, deadletter.ReplayerFunc(func(ctx context.Context, entry *deadletter.Entry) error {
+		done := a.drain.Begin(drain.KindJob)
+		defer done()
+		return a.commands.Handle(ctx, &kafka.Message{
+			Topic:   entry.Headers[kafka.HeaderTopic],
+			Value:   entry.Payload,
+			Headers: entry.Headers,
+		})
+	}))
+}
+
+// commandDeadLetters records commands the consumer dead-letters in the
+// dead-letter queue as well as on the dead-letter topic
+type commandDeadLetters struct {
+	service *deadletter.Service
+}
+
+// RecordDeadLetter implements kafka.Recorder
+func (r commandDeadLetters) RecordDeadLetter(ctx context.Context, message *kafka.Message, cause error, attempts int) error {
+	headers := make(map[string]string, len(message.Headers)+4)
+	for key, value := range message.Headers {
+		headers[key] = value
+	}
+	headers[kafka.HeaderTopic] = message.Topic
+	headers[kafka.HeaderPartition] = strconv.Itoa(int(message.Partition))
+	headers[kafka.HeaderOffset] = strconv.FormatInt(message.Offset, 10)
+	headers[kafka.HeaderAttempts] = strconv.Itoa(attempts)
+
+	key := fmt.Sprintf("%s/%d/%d", message.Topic, message.Partition, message.Offset)
+	_, err := r.service.Record(ctx, deadletter.SourceKafkaCommand, key, message.Value, headers, cause)
+	return err
+}
+
+// deadLetterErrorStatus maps dead-letter errors to HTTP status codes
+func deadLetterErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return fiber.StatusNotFound
+	case errors.Is(err, deadletter.ErrAlreadyResolved):
+		return fiber.StatusConflict
+	case errors.Is(err, deadletter.ErrInvalidPurge):
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// listDeadLetters handles listing dead-letter entries, optionally filtered by status and source
+func (a *App) listDeadLetters(c *fiber.Ctx) error {
+	entries, err := a.deadLetters.List(c.Context(), deadletter.Filter{
+		Status: c.Query("status"),
+		Source: c.Query("source"),
+		Limit:  c.QueryInt("limit", 100),
+	})
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(fiber.Map{"data": entries})
+}
+
+// getDeadLetter handles retrieving a dead-letter entry
+func (a *App) getDeadLetter(c *fiber.Ctx) error {
+	entry, err := a.deadLetters.Get(c.Context(), c.Params("id"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return a.errorMessage(c, fiber.StatusNotFound, "Dead letter not found", i18n.KeyNotFound)
+	}
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(entry)
+}
+
+// retryDeadLetter handles replaying a dead-letter entry now. The entry is
+// returned with the outcome; a failed replay is not an error response.
+func (a *App) retryDeadLetter(c *fiber.Ctx) error {
+	entry, err := a.deadLetters.Retry(c.Context(), c.Params("id"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return a.errorMessage(c, fiber.StatusNotFound, "Dead letter not found", i18n.KeyNotFound)
+	}
+	if err != nil {
+		return a.errorResponse(c, deadLetterErrorStatus(err), err)
+	}
+
+	return c.JSON(entry)
+}
+
+// retryDueDeadLetters handles an on-demand run of the automatic retry
+func (a *App) retryDueDeadLetters(c *fiber.Ctx) error {
+	result, err := a.deadLetters.RetryDue(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":      err.Error(),
+			"request_id": requestID(c),
+			"result":     result,
+		})
+	}
+
+	return c.JSON(result)
+}
+
+// deleteDeadLetter handles removing a dead-letter entry
+func (a *App) deleteDeadLetter(c *fiber.Ctx) error {
+	deleted, err := a.deadLetters.Delete(c.Context(), c.Params("id"))
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+	if !deleted {
+		return a.errorMessage(c, fiber.StatusNotFound, "Dead letter not found", i18n.KeyNotFound)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// purgeDeadLetters handles removing resolved or exhausted entries. An
+// optional before query parameter (RFC 3339) keeps newer entries.
+func (a *App) purgeDeadLetters(c *fiber.Ctx) error {
+	var before time.Time
+	if raw := c.Query("before"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return a.errorMessage(c, fiber.StatusBadRequest, "before must be an RFC 3339 timestamp", i18n.KeyInvalidRequest)
+		}
+		before = parsed
+	}
+
+	purged, err := a.deadLetters.Purge(c.Context(), c.Query("status"), before)
+	if err != nil {
+		return a.errorResponse(c, deadLetterErrorStatus(err), err)
+	}
+
+	return c.JSON(fiber.Map{"purged": purged})
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"time"
+
+	"apis/payments/services/deprecation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Deprecation notice IDs
+const (
+	deprecatedCreateCharge = "charges.create"
+	deprecatedGetCharge    = "charges.retrieve"
+	deprecatedListCharges  = "charges.list"
+	deprecatedSofort       = "payment_methods.type_sofort"
+	deprecatedChargeSource = "charges.field_source"
+)
+
+// chargesMigrationGuide explains moving from Charges to PaymentIntents
+const chargesMigrationGuide = "https://stripe.com/docs/payments/payment-intents/migration/charges"
+
+// deprecationNotices lists every deprecated route and field. Removing a
+// surface starts by adding it here and watching the usage report drain.
+var deprecationNotices = []deprecation.Notice{
+	{
+		ID:         deprecatedCreateCharge,
+		Surface:    "POST /api/v1/charges",
+		Message:    "The legacy Charges API is deprecated in favour of PaymentIntents",
+		Deprecated: time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC),
+		Sunset:     time.Date(2027, time.October, 1, 0, 0, 0, 0, time.UTC),
+		Link:       chargesMigrationGuide,
+	},
+	{
+		ID:         deprecatedGetCharge,
+		Surface:    "GET /api/v1/charges/:id",
+		Message:    "The legacy Charges API is deprecated in favour of PaymentIntents",
+		Deprecated: time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC),
+		Sunset:     time.Date(2027, time.October, 1, 0, 0, 0, 0, time.UTC),
+		Link:       chargesMigrationGuide,
+	},
+	{
+		ID:         deprecatedListCharges,
+		Surface:    "GET /api/v1/charges",
+		Message:    "The legacy Charges API is deprecated in favour of PaymentIntents",
+		Deprecated: time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC),
+		Sunset:     time.Date(2027, time.October, 1, 0, 0, 0, 0, time.UTC),
+		Link:       chargesMigrationGuide,
+	},
+	{
+		ID:         deprecatedSofort,
+		Surface:    "PaymentMethodRequest.type=sofort",
+		Message:    "Sofort is being retired by Stripe; offer Klarna or SEPA Direct Debit instead",
+		Deprecated: time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC),
+	},
+	{
+		ID:         deprecatedChargeSource,
+		Surface:    "ChargeRequest.source",
+		Message:    "Charge sources are deprecated; pass payment_method with a pm_ ID or vault pmt_ token instead",
+		Deprecated: time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC),
+		Link:       "https://stripe.com/docs/payments/payment-methods/transitioning",
+	},
+}
+
+// deprecated returns middleware marking a route as deprecated
+func (a *App) deprecated(noticeID string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		a.useDeprecated(c, noticeID)
+		return c.Next()
+	}
+}
+
+// useDeprecated announces a deprecated route or field on the response and
+// counts its use against the caller's API key. Handlers call it directly
+// when a request uses a deprecated field.
+func (a *App) useDeprecated(c *fiber.Ctx, noticeID string) {
+	notice, ok := a.deprecations.Notice(noticeID)
+	if !ok {
+		return
+	}
+
+	for header, value := range notice.Headers() {
+		c.Set(header, value)
+	}
+
+	apiKeyID := requestAPIKey(c)
+	if apiKeyID == "" {
+		apiKeyID = "anonymous"
+	}
+	a.deprecations.Track(notice.ID, apiKeyID)
+}
+
+// getDeprecationReport returns usage of every deprecated surface per API key
+func (a *App) getDeprecationReport(c *fiber.Ctx) error {
+	report, err := a.deprecations.Report(c.Context())
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"deprecations": report,
+	})
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+
+	"apis/payments/services/disputes"
+	"apis/payments/services/i18n"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// getDispute returns a dispute with its evidence, evidence deadline and
+// network reason code
+func (a *App) getDispute(c *fiber.Ctx) error {
+	disputeID := c.Params("id")
+	if disputeID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Dispute ID is required", i18n.KeyMissingParameter)
+	}
+
+	dispute, err := a.disputes.Get(c.Context(), disputeID)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusNotFound, err)
+	}
+
+	return c.JSON(dispute)
+}
+
+// listDisputes lists synced disputes, optionally by charge and status
+func (a *App) listDisputes(c *fiber.Ctx) error {
+	filter := disputes.Filter{
+		ChargeID: c.Query("charge_id"),
+		Status:   c.Query("status"),
+		Limit:    c.QueryInt("limit", 0),
+	}
+
+	result, err := a.disputes.List(c.Context(), filter)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(result)
+}
+
+// disputeEvidenceRequest stages evidence fields keyed by Stripe's evidence
+// field names
+type disputeEvidenceRequest struct {
+	Fields map[string]string `json:"fields"`
+}
+
+// disputeEvidenceErrorStatus maps dispute evidence errors to HTTP statuses
+func disputeEvidenceErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, disputes.ErrInvalidEvidence):
+		return fiber.StatusUnprocessableEntity
+	case errors.Is(err, disputes.ErrDisputeClosed), errors.Is(err, disputes.ErrEvidencePastDue), errors.Is(err, disputes.ErrNoEvidence):
+		return fiber.StatusConflict
+	default:
+		return fiber.StatusBadRequest
+	}
+}
+
+// getDisputeEvidence returns the evidence staged for a dispute with its
+// files and deadline
+func (a *App) getDisputeEvidence(c *fiber.Ctx) error {
+	evidence, err := a.disputeEvidence.Get(c.Context(), c.Params("id"))
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusNotFound, err)
+	}
+
+	return c.JSON(evidence)
+}
+
+// updateDisputeEvidence stages evidence fields on a dispute
+func (a *App) updateDisputeEvidence(c *fiber.Ctx) error {
+	var request disputeEvidenceRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	evidence, err := a.disputeEvidence.Update(c.Context(), c.Params("id"), request.Fields, c.Get("X-Operator-ID"))
+	if err != nil {
+		return a.errorResponse(c, disputeEvidenceErrorStatus(err), err)
+	}
+
+	return c.JSON(evidence)
+}
+
+// uploadDisputeEvidenceFile uploads a multipart evidence file, attaching it
+// to the file field named in the form's field value if given
+func (a *App) uploadDisputeEvidenceFile(c *fiber.Ctx) error {
+	header, err := c.FormFile("file")
+	if err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "A file is required", i18n.KeyMissingParameter)
+	}
+
+	content, err := header.Open()
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+	defer content.Close()
+
+	file, err := a.disputeEvidence.Upload(c.Context(), c.Params("id"), &disputes.Upload{
+		Field:      c.FormValue("field"),
+		Filename:   header.Filename,
+		Content:    content,
+		UploadedBy: c.Get("X-Operator-ID"),
+	})
+	if err != nil {
+		return a.errorResponse(c, disputeEvidenceErrorStatus(err), err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(file)
+}
+
+// submitDisputeEvidence submits a dispute's staged evidence to the bank
+func (a *App) submitDisputeEvidence(c *fiber.Ctx) error {
+	evidence, err := a.disputeEvidence.Submit(c.Context(), c.Params("id"), c.Get("X-Operator-ID"))
+	if err != nil {
+		return a.errorResponse(c, disputeEvidenceErrorStatus(err), err)
+	}
+
+	return c.JSON(evidence)
+}
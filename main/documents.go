@@ -0,0 +1,250 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"apis/payments/services/documents"
+	"apis/payments/services/i18n"
+	"apis/payments/services/receipts"
+	"apis/payments/services/stripe"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// documentErrorStatus maps document errors to HTTP status codes
+func documentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, documents.ErrInvalidTemplate), errors.Is(err, documents.ErrUnsupportedLanguage):
+		return fiber.StatusBadRequest
+	case errors.Is(err, documents.ErrStorageUnavailable):
+		return fiber.StatusServiceUnavailable
+	case errors.Is(err, sql.ErrNoRows):
+		return fiber.StatusNotFound
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// documentsDisabled answers requests for documents when no storage is configured
+func (a *App) documentsDisabled(c *fiber.Ctx) error {
+	return a.errorMessage(c, fiber.StatusServiceUnavailable, "Document storage is not configured", i18n.KeyGenericError)
+}
+
+// unsupportedDocumentLanguage answers requests for documents in a language
+// they are not translated to
+func (a *App) unsupportedDocumentLanguage(c *fiber.Ctx) error {
+	return a.errorMessage(c, fiber.StatusBadRequest,
+		fmt.Sprintf("language must be one of %s", strings.Join(documents.Languages(), ", ")), i18n.KeyInvalidRequest)
+}
+
+// getInvoiceDocument handles rendering a stored invoice as a branded PDF,
+// returning its signed download URL. ?language= overrides the template's.
+func (a *App) getInvoiceDocument(c *fiber.Ctx) error {
+	if !a.documents.Enabled() {
+		return a.documentsDisabled(c)
+	}
+	language := strings.ToLower(c.Query("language"))
+	if language != "" && !documents.SupportsLanguage(language) {
+		return a.unsupportedDocumentLanguage(c)
+	}
+
+	invoice, err := a.invoicing.Get(c.Context(), c.Params("id"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return a.errorMessage(c, fiber.StatusNotFound, "Invoice not found", i18n.KeyNotFound)
+	}
+	if err != nil {
+		return a.errorResponse(c, invoiceErrorStatus(err), err)
+	}
+
+	settings, err := a.receipts.Settings(c.Context(), requestTenant(c))
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	document, err := a.documents.Publish(c.Context(), requestTenant(c), language, invoiceDocument(invoice, settings))
+	if err != nil {
+		return a.errorResponse(c, documentErrorStatus(err), err)
+	}
+
+	return c.JSON(document)
+}
+
+// getChargeReceiptDocument handles rendering a charge's receipt as a
+// branded PDF, returning its signed download URL. ?language= overrides the
+// template's.
+func (a *App) getChargeReceiptDocument(c *fiber.Ctx) error {
+	if !a.documents.Enabled() {
+		return a.documentsDisabled(c)
+	}
+	language := strings.ToLower(c.Query("language"))
+	if language != "" && !documents.SupportsLanguage(language) {
+		return a.unsupportedDocumentLanguage(c)
+	}
+
+	chargeID := c.Params("id")
+	if chargeID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Charge ID is required", i18n.KeyMissingParameter)
+	}
+	charge, err := a.chargeCache.Get(c.Context(), chargeID)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusNotFound, err)
+	}
+
+	settings, err := a.receipts.Settings(c.Context(), requestTenant(c))
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	document, err := a.documents.Publish(c.Context(), requestTenant(c), language, chargeDocument(charge, settings))
+	if err != nil {
+		return a.errorResponse(c, documentErrorStatus(err), err)
+	}
+
+	return c.JSON(document)
+}
+
+// getDocument handles retrieving a published document with a freshly
+// signed download URL
+func (a *App) getDocument(c *fiber.Ctx) error {
+	if !a.documents.Enabled() {
+		return a.documentsDisabled(c)
+	}
+
+	document, err := a.documents.Get(c.Context(), requestTenant(c), c.Params("id"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return a.errorMessage(c, fiber.StatusNotFound, "Document not found", i18n.KeyNotFound)
+	}
+	if err != nil {
+		return a.errorResponse(c, documentErrorStatus(err), err)
+	}
+
+	return c.JSON(document)
+}
+
+// getDocumentTemplate handles retrieving the tenant's template for a kind
+// of document
+func (a *App) getDocumentTemplate(c *fiber.Ctx) error {
+	template, err := a.documents.Template(c.Context(), requestTenant(c), c.Params("kind"))
+	if err != nil {
+		return a.errorResponse(c, documentErrorStatus(err), err)
+	}
+
+	return c.JSON(template)
+}
+
+// updateDocumentTemplate handles saving the tenant's template for a kind of
+// document
+func (a *App) updateDocumentTemplate(c *fiber.Ctx) error {
+	var template documents.Template
+	if err := c.BodyParser(&template); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+	template.TenantID = requestTenant(c)
+	template.Kind = c.Params("kind")
+
+	saved, err := a.documents.UpdateTemplate(c.Context(), &template)
+	if err != nil {
+		return a.errorResponse(c, documentErrorStatus(err), err)
+	}
+
+	return c.JSON(saved)
+}
+
+// deleteDocumentTemplate handles removing the tenant's template for a kind
+// of document
+func (a *App) deleteDocumentTemplate(c *fiber.Ctx) error {
+	deleted, err := a.documents.DeleteTemplate(c.Context(), requestTenant(c), c.Params("kind"))
+	if err != nil {
+		return a.errorResponse(c, documentErrorStatus(err), err)
+	}
+	if !deleted {
+		return a.errorMessage(c, fiber.StatusNotFound, "Document template not found", i18n.KeyNotFound)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// invoiceDocument describes a stored invoice for a branded PDF. Invoices
+// synced before totals were stored are shown as one line of the amount due.
+func invoiceDocument(invoice *stripe.Invoice, settings *receipts.Settings) *documents.Content {
+	content := &documents.Content{
+		Kind:          documents.KindInvoice,
+		SourceID:      invoice.ID,
+		Number:        invoice.Number,
+		Status:        invoice.Status,
+		Currency:      invoice.Currency,
+		IssuedAt:      time.Unix(invoice.Created, 0),
+		DueAt:         invoice.DueDate,
+		CustomerName:  invoice.CustomerName,
+		CustomerEmail: invoice.CustomerEmail,
+		Description:   invoice.Description,
+		Subtotal:      invoice.Subtotal,
+		Total:         invoice.Total,
+		AmountPaid:    invoice.AmountPaid,
+		AmountDue:     invoice.AmountRemaining,
+		BusinessName:  settings.BusinessName,
+		SupportEmail:  settings.SupportEmail,
+	}
+	if content.Number == "" {
+		content.Number = invoice.ID
+	}
+	if len(invoice.Lines) == 0 && invoice.Total == 0 {
+		content.Subtotal = invoice.AmountDue
+		content.Total = invoice.AmountDue
+	}
+	for _, line := range invoice.Lines {
+		content.Lines = append(content.Lines, documents.Line{
+			Description: line.Description,
+			Quantity:    line.Quantity,
+			UnitAmount:  line.UnitAmount,
+			Amount:      line.Amount,
+		})
+	}
+	for _, tax := range invoice.TaxAmounts {
+		content.Taxes = append(content.Taxes, documents.TaxLine{
+			Name:       tax.Name,
+			Percentage: tax.Percentage,
+			Inclusive:  tax.Inclusive,
+			Amount:     tax.Amount,
+		})
+	}
+	return content
+}
+
+// chargeDocument describes a charge for a branded PDF receipt
+func chargeDocument(charge *stripe.Charge, settings *receipts.Settings) *documents.Content {
+	content := &documents.Content{
+		Kind:                documents.KindReceipt,
+		SourceID:            charge.ID,
+		Number:              charge.ReceiptNumber,
+		Status:              charge.Status,
+		Currency:            charge.Currency,
+		IssuedAt:            time.Unix(charge.Created, 0),
+		CustomerEmail:       charge.ReceiptEmail,
+		Description:         charge.Description,
+		Subtotal:            charge.Amount,
+		Total:               charge.Amount,
+		AmountRefunded:      charge.AmountRefunded,
+		PaymentMethod:       charge.CardNetwork,
+		StatementDescriptor: charge.StatementDescriptor,
+		BusinessName:        settings.BusinessName,
+		SupportEmail:        settings.SupportEmail,
+	}
+	if content.Number == "" {
+		content.Number = charge.ID
+	}
+	if content.PaymentMethod == "" {
+		content.PaymentMethod = charge.PaymentMethodType
+	}
+	if charge.Status == "succeeded" {
+		content.AmountPaid = charge.Amount
+		if charge.AmountCaptured > 0 {
+			content.AmountPaid = charge.AmountCaptured
+		}
+	}
+	return content
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"strings"
+
+	"apis/payments/services/drain"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// trackInFlight counts each request until its handler returns so shutdown
+// can wait for in-flight charges and webhook deliveries to finish
+func (a *App) trackInFlight(c *fiber.Ctx) error {
+	kind := drain.KindRequest
+	if strings.HasPrefix(c.Path(), "/webhooks/") {
+		kind = drain.KindWebhook
+	}
+
+	done := a.drain.Begin(kind)
+	defer done()
+
+	return c.Next()
+}
+
+// ready reports whether the instance should receive traffic. It returns 503
+// once shutdown has begun so load balancers stop routing new requests here
+// while in-flight ones finish. Workers report the jobs they are running.
+func (a *App) ready(c *fiber.Ctx) error {
+	if !a.drain.Ready() {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"status":    "draining",
+			"role":      a.runMode,
+			"in_flight": a.drain.InFlight(),
+		})
+	}
+
+	response := fiber.Map{
+		"status": "ready",
+		"role":   a.runMode,
+	}
+	if a.runMode.RunsWorkers() {
+		response["jobs_in_flight"] = a.drain.InFlight()[drain.KindJob]
+	}
+
+	return c.JSON(response)
+}
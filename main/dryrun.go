@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+
+	"apis/payments/services/chargestate"
+	"apis/payments/services/customfields"
+	"apis/payments/services/dryrun"
+	"apis/payments/services/fraud"
+	"apis/payments/services/metadata"
+	"apis/payments/services/money"
+	"apis/payments/services/refundguard"
+	"apis/payments/services/stripe"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// dryRunCharge runs every check a charge would go through and reports the
+// provider, estimated fee and rules matched, without creating the charge
+func (a *App) dryRunCharge(c *fiber.Ctx, request *stripe.ChargeRequest, customFields map[string]string) error {
+	ctx := c.Context()
+	result := dryrun.New(dryrun.OperationCharge)
+
+	result.Check("metadata", a.checkMetadata(c, metadata.ResourceCharge, request.Metadata))
+
+	fieldMetadata, rendered, err := a.collectCustomFields(c, request.Metadata, customFields)
+	if result.Check("custom_fields", err) {
+		request.Metadata = fieldMetadata
+		request.Description = customfields.ReceiptDescription(request.Description, rendered)
+	}
+
+	for _, field := range []*string{&request.PaymentMethod, &request.Source} {
+		if *field == "" {
+			continue
+		}
+		resolved, err := a.resolvePaymentMethod(ctx, *field)
+		if result.Check("payment_method", err) {
+			*field = resolved
+		}
+	}
+
+	decision := a.router.Route(ctx, request.Currency)
+	result.Route(decision)
+	result.Check("routing", routeError(decision))
+
+	amount, err := money.ResolveAmount(request.Amount, request.AmountDecimal, request.Currency)
+	if result.Check("amount", err) {
+		request.Amount, request.AmountDecimal = amount, ""
+	}
+	result.Amount, result.Currency = request.Amount, request.Currency
+
+	result.Check("validation", a.chargeService.ValidateChargeRequest(request))
+	result.Check("capabilities", dryrun.CheckCapabilities(decision.Provider, dryrun.OperationCharge, request.Amount))
+	result.Check("fraud_screening", a.chargeService.ScreenCharge(ctx, request.CustomerID))
+	result.Check("budget", a.budgets.CheckCharge(ctx, requestTenant(c), request.Currency, request.Amount))
+
+	if a.fraud.Enabled() {
+		assessment, err := a.fraud.Assess(ctx, a.fraudScreening(c, request))
+		if result.Check("fraud_rules", err) {
+			for _, finding := range assessment.Findings {
+				result.Match("fraud_"+finding.Rule, finding.Reason)
+			}
+			switch assessment.Decision {
+			case fraud.DecisionReject:
+				result.Check("fraud_decision", fraud.ErrRejected)
+			case fraud.DecisionReview:
+				result.RequireApproval()
+			}
+		}
+	}
+
+	result.EstimatedFee = a.dryRunConfig.EstimateFee(decision.Provider, request.Currency, request.Amount)
+
+	return c.JSON(result)
+}
+
+// dryRunRefund runs every check a refund would go through, including the
+// refund limits that would hold it for approval, without issuing it
+func (a *App) dryRunRefund(c *fiber.Ctx, request *stripe.RefundRequest) error {
+	ctx := c.Context()
+	result := dryrun.New(dryrun.OperationRefund)
+	result.Provider = vaultProvider
+
+	result.Check("metadata", a.checkMetadata(c, metadata.ResourceRefund, request.Metadata))
+	result.Check("charge_state", a.chargeStates.Allows(ctx, request.ChargeID, chargestate.StatePartiallyRefunded, chargestate.StateRefunded))
+
+	amount, violations, err := a.refundGuard.Preview(ctx, refundActor(c), request)
+	if result.Check("refund_limits", err) {
+		result.Amount = amount
+		for _, violation := range violations {
+			if violation.Dimension == refundguard.DimensionRefund {
+				result.Match("refund_limit", fmt.Sprintf("refund of %d is over the approval threshold of %d", violation.Usage.Amount, violation.Threshold.Amount))
+				continue
+			}
+			result.Match("refund_limit", fmt.Sprintf("%s %s would reach %d refunds totalling %d", violation.Dimension, violation.Key, violation.Usage.Count, violation.Usage.Amount))
+		}
+		if len(violations) > 0 {
+			result.RequireApproval()
+		}
+	}
+
+	result.Check("validation", a.refundService.ValidateRefundRequest(request))
+	result.Check("capabilities", dryrun.CheckCapabilities(vaultProvider, dryrun.OperationRefund, 0))
+
+	return c.JSON(result)
+}
+
+// dryRunInvoicedSubscription runs every check an invoiced subscription
+// would go through without creating it
+func (a *App) dryRunInvoicedSubscription(c *fiber.Ctx, request *stripe.InvoicedSubscriptionRequest, customFields map[string]string) error {
+	result := dryrun.New(dryrun.OperationSubscription)
+	result.Provider = vaultProvider
+
+	fieldMetadata, _, err := a.collectCustomFields(c, request.Metadata, customFields)
+	if result.Check("custom_fields", err) {
+		request.Metadata = fieldMetadata
+	}
+
+	_, err = a.subscriptionService.ValidateInvoicedSubscription(request)
+	result.Check("validation", err)
+	result.Check("capabilities", dryrun.CheckCapabilities(vaultProvider, dryrun.OperationSubscription, 0))
+
+	return c.JSON(result)
+}
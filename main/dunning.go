@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	"apis/payments/services/dunning"
+	"apis/payments/services/i18n"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// dunningErrorStatus maps dunning errors to HTTP statuses. Provider errors
+// while retrying are recorded on the case, not returned.
+func dunningErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, dunning.ErrCaseNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, dunning.ErrCaseResolved):
+		return fiber.StatusConflict
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// listDunningCases handles listing dunning cases, optionally in one state
+// or of one customer or subscription
+func (a *App) listDunningCases(c *fiber.Ctx) error {
+	cases, err := a.dunning.List(c.Context(), dunning.Filter{
+		State:          c.Query("state"),
+		CustomerID:     c.Query("customer_id"),
+		SubscriptionID: c.Query("subscription_id"),
+		Limit:          c.QueryInt("limit"),
+	})
+	if err != nil {
+		return a.errorResponse(c, dunningErrorStatus(err), err)
+	}
+
+	return c.JSON(fiber.Map{"data": cases})
+}
+
+// getDunningCase handles dunning case retrieval
+func (a *App) getDunningCase(c *fiber.Ctx) error {
+	caseID := c.Params("id")
+	if caseID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Dunning case ID is required", i18n.KeyMissingParameter)
+	}
+
+	dunningCase, err := a.dunning.Get(c.Context(), caseID)
+	if err != nil {
+		return a.errorResponse(c, dunningErrorStatus(err), err)
+	}
+
+	return c.JSON(dunningCase)
+}
+
+// retryDunningCase handles retrying payment of an open dunning case now
+func (a *App) retryDunningCase(c *fiber.Ctx) error {
+	caseID := c.Params("id")
+	if caseID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Dunning case ID is required", i18n.KeyMissingParameter)
+	}
+
+	dunningCase, err := a.dunning.Retry(c.Context(), caseID)
+	if err != nil {
+		return a.errorResponse(c, dunningErrorStatus(err), err)
+	}
+
+	return c.JSON(dunningCase)
+}
+
+// runDunning handles advancing dunning cases immediately
+func (a *App) runDunning(c *fiber.Ctx) error {
+	changed, err := a.dunning.Advance(c.Context(), time.Now())
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"cases_changed": changed,
+	})
+}
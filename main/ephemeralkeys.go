@@ -0,0 +1,171 @@
+package main
+
+import (
+	"errors"
+	"strings"
+
+	"apis/payments/services/ephemeralkeys"
+	"apis/payments/services/i18n"
+	"apis/payments/services/metadata"
+	"apis/payments/services/requestid"
+	"apis/payments/services/stripe"
+	"apis/payments/services/vault"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ephemeralKeyLocal is the fiber.Ctx local holding the authenticated ephemeral key
+const ephemeralKeyLocal = "ephemeralKey"
+
+// createEphemeralKey issues a short-lived key for one customer. Backends call
+// this and hand the secret to their frontend.
+func (a *App) createEphemeralKey(c *fiber.Ctx) error {
+	var request ephemeralkeys.IssueRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+	if request.CustomerID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Customer ID is required", i18n.KeyMissingParameter)
+	}
+
+	if _, err := a.customerService.GetCustomer(c.Context(), request.CustomerID); err != nil {
+		return a.errorResponse(c, fiber.StatusNotFound, err)
+	}
+
+	request.IssuedBy = requestAPIKey(c)
+	key, err := a.ephemeralKeys.Issue(c.Context(), &request)
+	if errors.Is(err, ephemeralkeys.ErrUnknownScope) {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(key)
+}
+
+// revokeEphemeralKey invalidates an ephemeral key, e.g. when the user logs out
+func (a *App) revokeEphemeralKey(c *fiber.Ctx) error {
+	err := a.ephemeralKeys.Revoke(c.Context(), c.Params("id"))
+	if errors.Is(err, ephemeralkeys.ErrInvalidKey) {
+		return a.errorResponse(c, fiber.StatusNotFound, err)
+	}
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ephemeralKeyAuth authenticates client routes with an ephemeral key secret
+// and requires the given scope
+func (a *App) ephemeralKeyAuth(scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		secret := strings.TrimPrefix(c.Get("Authorization"), "Bearer ")
+
+		key, err := a.ephemeralKeys.Authenticate(c.Context(), secret)
+		if errors.Is(err, ephemeralkeys.ErrInvalidKey) {
+			return a.errorMessage(c, fiber.StatusUnauthorized, err.Error(), i18n.KeyUnauthorized)
+		}
+		if err != nil {
+			return a.errorResponse(c, fiber.StatusInternalServerError, err)
+		}
+		if !key.Allows(scope) {
+			return a.errorMessage(c, fiber.StatusForbidden, ephemeralkeys.ErrScopeNotAllowed.Error(), i18n.KeyNotPermitted)
+		}
+
+		c.Locals(ephemeralKeyLocal, key)
+		return c.Next()
+	}
+}
+
+// clientCustomer returns the customer the request's ephemeral key was issued for
+func clientCustomer(c *fiber.Ctx) string {
+	return c.Locals(ephemeralKeyLocal).(*ephemeralkeys.Key).CustomerID
+}
+
+// listClientPaymentMethods lists the key holder's own payment methods
+func (a *App) listClientPaymentMethods(c *fiber.Ctx) error {
+	page, err := pageRequest(c)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	paymentMethods, err := a.customerService.ListPaymentMethods(c.Context(), clientCustomer(c), page)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+	paymentMethods, hasMore, nextCursor := trimPage(paymentMethods, page, func(paymentMethod *stripe.PaymentMethod) string {
+		return paymentMethod.ID
+	})
+
+	vaulted := make([]*vaultedPaymentMethod, len(paymentMethods))
+	for i, paymentMethod := range paymentMethods {
+		if vaulted[i], err = a.vaultPaymentMethod(c.Context(), paymentMethod); err != nil {
+			return a.errorResponse(c, fiber.StatusInternalServerError, err)
+		}
+	}
+
+	return c.JSON(pageResponse(vaulted, hasMore, nextCursor))
+}
+
+// addClientPaymentMethod attaches a tokenized card to the key holder
+func (a *App) addClientPaymentMethod(c *fiber.Ctx) error {
+	var request stripe.PaymentMethodRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+	request.Customer = clientCustomer(c)
+
+	if err := a.checkMetadata(c, metadata.ResourcePaymentMethod, request.Metadata); err != nil {
+		return a.errorResponse(c, metadataErrorStatus(err), err)
+	}
+
+	paymentMethod, err := a.customerService.AddPaymentMethod(c.Context(), &request)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	// Card fingerprints are only known once the card is attached
+	if err := a.checkPaymentMethodBlocked(c.Context(), requestTenant(c), paymentMethod); err != nil {
+		return a.errorResponse(c, blocklistErrorStatus(err), err)
+	}
+
+	vaulted, err := a.vaultPaymentMethod(c.Context(), paymentMethod)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(vaulted)
+}
+
+// detachClientPaymentMethod detaches one of the key holder's payment methods.
+// Payment methods of other customers are reported as not found.
+func (a *App) detachClientPaymentMethod(c *fiber.Ctx) error {
+	paymentMethodID := c.Params("id")
+
+	providerID, err := a.resolvePaymentMethod(c.Context(), paymentMethodID)
+	if err != nil {
+		return a.errorResponse(c, vaultErrorStatus(err), err)
+	}
+
+	paymentMethod, err := a.customerService.GetPaymentMethod(c.Context(), providerID)
+	if err != nil || paymentMethod.Customer != clientCustomer(c) {
+		return a.errorMessage(c, fiber.StatusNotFound, "Payment method not found", i18n.KeyNotFound)
+	}
+
+	if err := a.customerService.DetachPaymentMethod(c.Context(), providerID); err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+	if err := a.paymentMethods.Forget(c.Context(), requestTenant(c), clientCustomer(c), providerID); err != nil {
+		requestid.Logf(c.Context(), "Failed to update payment method preference after detaching %s: %v", providerID, err)
+	}
+
+	if vault.IsToken(paymentMethodID) {
+		if err := a.vault.Revoke(c.Context(), paymentMethodID); err != nil {
+			return a.errorResponse(c, fiber.StatusInternalServerError, err)
+		}
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// errorResponse writes an error along with a localized, customer-safe display
+// message and the request ID to quote when reporting it
+func (a *App) errorResponse(c *fiber.Ctx, status int, err error) error {
+	return c.Status(status).JSON(fiber.Map{
+		"error":           err.Error(),
+		"display_message": a.translator.Localize(err, c.Get("Accept-Language")),
+		"request_id":      requestID(c),
+	})
+}
+
+// errorMessage writes a fixed error message along with the localized display message for key
+func (a *App) errorMessage(c *fiber.Ctx, status int, message, key string) error {
+	return c.Status(status).JSON(fiber.Map{
+		"error":           message,
+		"display_message": a.translator.Message(key, c.Get("Accept-Language")),
+		"request_id":      requestID(c),
+	})
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"apis/payments/services/eventarchive"
+	"apis/payments/services/i18n"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// eventReplayErrorStatus maps event replay errors to HTTP status codes
+func eventReplayErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, eventarchive.ErrInvalidReplay):
+		return fiber.StatusBadRequest
+	case errors.Is(err, eventarchive.ErrTopicsUnavailable):
+		return fiber.StatusServiceUnavailable
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// eventArchiveDisabled answers requests to the event archive when it is not enabled
+func (a *App) eventArchiveDisabled(c *fiber.Ctx) error {
+	return a.errorMessage(c, fiber.StatusServiceUnavailable, "The event archive is not enabled", i18n.KeyGenericError)
+}
+
+// listArchivedEvents handles listing archived events, oldest first. from
+// and to (RFC 3339) bound when they occurred and type, subject and
+// tenant_id narrow them; after_time and after_id continue from the time and
+// ID of the last event of a previous page.
+func (a *App) listArchivedEvents(c *fiber.Ctx) error {
+	if !a.eventArchive.Enabled() {
+		return a.eventArchiveDisabled(c)
+	}
+
+	filter := eventarchive.Filter{
+		TenantID: c.Query("tenant_id"),
+		Type:     c.Query("type"),
+		Subject:  c.Query("subject"),
+		Limit:    c.QueryInt("limit", 100),
+	}
+	for param, bound := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		if raw := c.Query(param); raw != "" {
+			parsed, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return a.errorMessage(c, fiber.StatusBadRequest, param+" must be an RFC 3339 timestamp", i18n.KeyInvalidRequest)
+			}
+			*bound = parsed
+		}
+	}
+	if raw := c.Query("after_time"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			return a.errorMessage(c, fiber.StatusBadRequest, "after_time must be an RFC 3339 timestamp", i18n.KeyInvalidRequest)
+		}
+		filter.After = &eventarchive.Cursor{Time: parsed, ID: c.Query("after_id")}
+	}
+
+	records, err := a.eventArchive.List(c.Context(), filter)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(fiber.Map{"data": records})
+}
+
+// replayEvents handles publishing archived events again to a topic or a
+// tenant's webhook endpoint. A failed replay answers with how far it got.
+func (a *App) replayEvents(c *fiber.Ctx) error {
+	if !a.eventArchive.Enabled() {
+		return a.eventArchiveDisabled(c)
+	}
+
+	var request eventarchive.ReplayRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	result, err := a.eventArchive.Replay(c.Context(), request)
+	if errors.Is(err, sql.ErrNoRows) {
+		return a.errorMessage(c, fiber.StatusNotFound, "Webhook endpoint not found", i18n.KeyNotFound)
+	}
+	if err != nil && result != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":      err.Error(),
+			"request_id": requestID(c),
+			"result":     result,
+		})
+	}
+	if err != nil {
+		return a.errorResponse(c, eventReplayErrorStatus(err), err)
+	}
+
+	return c.JSON(result)
+}
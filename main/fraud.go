@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"apis/payments/services/chargestate"
+	"apis/payments/services/fraud"
+	"apis/payments/services/i18n"
+	"apis/payments/services/requestid"
+	"apis/payments/services/stripe"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// addFraudListEntryRequest allows or denies a customer, card fingerprint or IP address
+type addFraudListEntryRequest struct {
+	List   string `json:"list"`
+	Type   string `json:"type"`
+	Value  string `json:"value"`
+	Reason string `json:"reason"`
+}
+
+// fraudCharger creates charges that passed fraud screening, including held
+// charges approved later, along the same route and budget checks as the API
+type fraudCharger struct {
+	app *App
+}
+
+// CreateCharge routes, budgets and creates a charge, recording its routing,
+// spend and state
+func (f *fraudCharger) CreateCharge(ctx context.Context, tenantID string, request *stripe.ChargeRequest) (*stripe.Charge, error) {
+	a := f.app
+
+	decision := a.router.Route(ctx, request.Currency)
+	if err := routeError(decision); err != nil {
+		return nil, err
+	}
+	if err := a.budgets.CheckCharge(ctx, tenantID, request.Currency, request.Amount); err != nil {
+		return nil, err
+	}
+
+	// Declined charges are retried with the customer's backup payment methods
+	charge, err := a.paymentMethods.Charge(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.router.Record(ctx, charge.ID, charge.Amount, decision); err != nil {
+		requestid.Logf(ctx, "Failed to record routing for charge %s: %v", charge.ID, err)
+	}
+	if err := a.budgets.RecordCharge(ctx, tenantID, charge.Currency, charge.Amount); err != nil {
+		requestid.Logf(ctx, "Failed to record budget spend for charge %s: %v", charge.ID, err)
+	}
+	if _, err := a.chargeStates.Apply(ctx, charge, chargestate.SourceAPI, ""); err != nil {
+		requestid.Logf(ctx, "Failed to record state for charge %s: %v", charge.ID, err)
+	}
+
+	return charge, nil
+}
+
+// fraudScreening describes a charge request for fraud screening. The card
+// fingerprint is only looked up when screening or fingerprint checks are
+// enabled.
+func (a *App) fraudScreening(c *fiber.Ctx, request *stripe.ChargeRequest) *fraud.Screening {
+	screening := &fraud.Screening{
+		TenantID:   requestTenant(c),
+		CustomerID: request.CustomerID,
+		IPAddress:  c.IP(),
+		Amount:     request.Amount,
+		Currency:   strings.ToLower(request.Currency),
+	}
+
+	paymentMethodID := request.PaymentMethod
+	if paymentMethodID == "" {
+		paymentMethodID = request.Source
+	}
+	if (a.fraud.Enabled() || a.fingerprints.Enabled()) && paymentMethodID != "" {
+		paymentMethod, err := a.customerService.GetPaymentMethod(c.Context(), paymentMethodID)
+		if err != nil {
+			requestid.Logf(c.Context(), "Failed to look up card fingerprint for fraud screening: %v", err)
+		} else if paymentMethod.Card != nil {
+			screening.CardFingerprint = paymentMethod.Card.Fingerprint
+		}
+	}
+
+	return screening
+}
+
+// fraudErrorStatus maps fraud review and list errors to HTTP statuses
+func fraudErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, fraud.ErrReviewNotFound), errors.Is(err, fraud.ErrListEntryNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, fraud.ErrReviewNotPending):
+		return fiber.StatusConflict
+	case errors.Is(err, fraud.ErrInvalidListEntry):
+		return fiber.StatusUnprocessableEntity
+	default:
+		return fiber.StatusBadRequest
+	}
+}
+
+// listFraudReviews handles listing a tenant's fraud reviews
+func (a *App) listFraudReviews(c *fiber.Ctx) error {
+	reviews, err := a.fraud.ListReviews(c.Context(), requestTenant(c), c.Query("status"), c.QueryInt("limit", 100))
+	if err != nil {
+		return a.errorResponse(c, fraudErrorStatus(err), err)
+	}
+
+	return c.JSON(fiber.Map{"data": reviews})
+}
+
+// getFraudReview handles fraud review retrieval
+func (a *App) getFraudReview(c *fiber.Ctx) error {
+	review, err := a.tenantFraudReview(c)
+	if err != nil {
+		return a.errorResponse(c, fraudErrorStatus(err), err)
+	}
+
+	return c.JSON(review)
+}
+
+// approveFraudReview creates a charge held for review
+func (a *App) approveFraudReview(c *fiber.Ctx) error {
+	review, err := a.tenantFraudReview(c)
+	if err != nil {
+		return a.errorResponse(c, fraudErrorStatus(err), err)
+	}
+
+	approved, err := a.fraud.ApproveReview(c.Context(), review.ID, c.Get("X-Operator-ID"))
+	if err != nil {
+		if errors.Is(err, fraud.ErrReviewNotPending) || errors.Is(err, fraud.ErrReviewNotFound) {
+			return a.errorResponse(c, fraudErrorStatus(err), err)
+		}
+		return a.errorResponse(c, chargeErrorStatus(err), err)
+	}
+
+	return c.JSON(approved)
+}
+
+// rejectFraudReview rejects a charge held for review without creating it
+func (a *App) rejectFraudReview(c *fiber.Ctx) error {
+	review, err := a.tenantFraudReview(c)
+	if err != nil {
+		return a.errorResponse(c, fraudErrorStatus(err), err)
+	}
+
+	rejected, err := a.fraud.RejectReview(c.Context(), review.ID, c.Get("X-Operator-ID"))
+	if err != nil {
+		return a.errorResponse(c, fraudErrorStatus(err), err)
+	}
+
+	return c.JSON(rejected)
+}
+
+// tenantFraudReview loads the review named in the path, hiding other
+// tenants' reviews
+func (a *App) tenantFraudReview(c *fiber.Ctx) (*fraud.Review, error) {
+	review, err := a.fraud.GetReview(c.Context(), c.Params("id"))
+	if err != nil {
+		return nil, err
+	}
+	if review.TenantID != requestTenant(c) {
+		return nil, fraud.ErrReviewNotFound
+	}
+
+	return review, nil
+}
+
+// listFraudListEntries lists allow and deny list entries, optionally of one list
+func (a *App) listFraudListEntries(c *fiber.Ctx) error {
+	entries, err := a.fraud.ListEntries(c.Context(), c.Query("list"))
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(fiber.Map{"data": entries})
+}
+
+// addFraudListEntry allows or denies a customer, card fingerprint or IP address
+func (a *App) addFraudListEntry(c *fiber.Ctx) error {
+	var request addFraudListEntryRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	entry, err := a.fraud.AddListEntry(c.Context(), &fraud.ListEntry{
+		List:      request.List,
+		Type:      request.Type,
+		Value:     request.Value,
+		Reason:    request.Reason,
+		CreatedBy: c.Get("X-Operator-ID"),
+	})
+	if err != nil {
+		return a.errorResponse(c, fraudErrorStatus(err), err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(entry)
+}
+
+// removeFraudListEntry removes an allow or deny list entry
+func (a *App) removeFraudListEntry(c *fiber.Ctx) error {
+	if err := a.fraud.RemoveListEntry(c.Context(), c.Params("id")); err != nil {
+		return a.errorResponse(c, fraudErrorStatus(err), err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"apis/payments/services/egress"
+	"apis/payments/services/instrumentation"
+	"apis/payments/services/resilience"
+	"apis/payments/services/stripe"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// defaultSlowCallThreshold is used when GATEWAY_SLOW_CALL_THRESHOLD_MS is not set
+const defaultSlowCallThreshold = time.Second
+
+// configureGateway routes every Stripe API call through an instrumented
+// transport using the provider's egress settings and returns the recorder
+// holding per-operation latencies
+func configureGateway() (*instrumentation.Recorder, error) {
+	threshold := defaultSlowCallThreshold
+	if value := os.Getenv("GATEWAY_SLOW_CALL_THRESHOLD_MS"); value != "" {
+		ms, err := strconv.Atoi(value)
+		if err != nil {
+			log.Printf("Invalid GATEWAY_SLOW_CALL_THRESHOLD_MS %q, using %s", value, threshold)
+		} else {
+			threshold = time.Duration(ms) * time.Millisecond
+		}
+	}
+
+	egressConfig, err := egress.LoadConfig("stripe")
+	if err != nil {
+		return nil, err
+	}
+	transport, err := egress.NewTransport(egressConfig)
+	if err != nil {
+		return nil, err
+	}
+	if egressConfig.ProxyURL != nil {
+		log.Printf("Routing stripe calls through egress proxy %s", egressConfig.ProxyURL.Redacted())
+	}
+
+	recorder := instrumentation.NewRecorder()
+	stripe.Configure(os.Getenv("STRIPE_SECRET_KEY"), &http.Client{
+		Transport: instrumentation.NewTransport(transport, "stripe", recorder, threshold),
+		Timeout:   egressConfig.RequestTimeout,
+	})
+
+	return recorder, nil
+}
+
+// getGatewayLatency returns latency percentiles for every provider operation
+func (a *App) getGatewayLatency(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"operations": a.gatewayRecorder.Snapshot(),
+	})
+}
+
+// getGatewayBreakers returns the circuit breaker state of every provider
+// operation called so far
+func (a *App) getGatewayBreakers(c *fiber.Ctx) error {
+	if a.gatewayResilience == nil {
+		return c.JSON(fiber.Map{"enabled": false, "breakers": []resilience.BreakerStatus{}})
+	}
+
+	return c.JSON(fiber.Map{
+		"enabled":  true,
+		"breakers": a.gatewayResilience.Breakers(),
+	})
+}
+
+// gatewayHealth summarises provider breakers for the health check. Open
+// breakers degrade the provider without failing the check, since restarting
+// the instance would not bring the provider back.
+func (a *App) gatewayHealth() fiber.Map {
+	if a.gatewayResilience == nil {
+		return fiber.Map{"status": "unmonitored"}
+	}
+
+	open := a.gatewayResilience.Open()
+	if len(open) == 0 {
+		return fiber.Map{"status": "ok"}
+	}
+	return fiber.Map{"status": "degraded", "open_breakers": open}
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"apis/payments/services/disputes"
+	"apis/payments/services/graphql"
+	"apis/payments/services/i18n"
+	"apis/payments/services/stripe"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// graphqlListLimit is how many objects list fields return unless a limit is given
+const graphqlListLimit = 10
+
+// errGraphQLLimit is returned for list fields asked for more than a page
+var errGraphQLLimit = errors.New("limit must be between 1 and 100")
+
+// graphqlLoadersKey carries a request's loaders in its context
+type graphqlLoadersKey struct{}
+
+// graphqlLoaders fetch what the resolvers of one request look up, so an
+// object referenced from many places is fetched once and lookups of
+// sibling objects are batched
+type graphqlLoaders struct {
+	customers       *graphql.Loader[string, *stripe.Customer]
+	charges         *graphql.Loader[string, *stripe.Charge]
+	customerCharges *graphql.Loader[customerPage, []*stripe.Charge]
+	paymentMethods  *graphql.Loader[customerPage, []*vaultedPaymentMethod]
+	subscriptions   *graphql.Loader[customerSubscriptions, []*stripe.Subscription]
+	refunds         *graphql.Loader[string, []*stripe.Refund]
+	disputes        *graphql.Loader[string, []*stripe.Dispute]
+}
+
+// customerPage keys the first objects of a customer's list
+type customerPage struct {
+	customerID string
+	limit      int
+}
+
+// customerSubscriptions keys a customer's subscriptions in a status, or
+// their active ones when the status is empty
+type customerSubscriptions struct {
+	customerID string
+	status     string
+}
+
+// newGraphQLLoaders creates the loaders of one request
+func (a *App) newGraphQLLoaders() *graphqlLoaders {
+	wait, concurrency := a.graphqlConfig.LoaderWait, a.graphqlConfig.LoaderConcurrency
+
+	return &graphqlLoaders{
+		customers: graphql.NewLoader(graphql.PerKey(a.customerService.GetCustomer, concurrency), wait),
+		charges:   graphql.NewLoader(graphql.PerKey(a.chargeService.GetCharge, concurrency), wait),
+		customerCharges: graphql.NewLoader(graphql.PerKey(func(ctx context.Context, key customerPage) ([]*stripe.Charge, error) {
+			return a.chargeService.ListCharges(ctx, key.customerID, stripe.Page{Limit: int64(key.limit)})
+		}, concurrency), wait),
+		paymentMethods: graphql.NewLoader(graphql.PerKey(func(ctx context.Context, key customerPage) ([]*vaultedPaymentMethod, error) {
+			paymentMethods, err := a.customerService.ListPaymentMethods(ctx, key.customerID, stripe.Page{Limit: int64(key.limit)})
+			if err != nil {
+				return nil, err
+			}
+			vaulted := make([]*vaultedPaymentMethod, len(paymentMethods))
+			for i, paymentMethod := range paymentMethods {
+				if vaulted[i], err = a.vaultPaymentMethod(ctx, paymentMethod); err != nil {
+					return nil, err
+				}
+			}
+			return vaulted, nil
+		}, concurrency), wait),
+		subscriptions: graphql.NewLoader(graphql.PerKey(func(ctx context.Context, key customerSubscriptions) ([]*stripe.Subscription, error) {
+			if key.status == "" {
+				return a.subscriptionService.ListActiveSubscriptions(ctx, key.customerID)
+			}
+			return a.subscriptionService.ListSubscriptions(ctx, key.customerID, key.status, stripe.Page{Limit: stripe.MaxPageLimit})
+		}, concurrency), wait),
+		refunds: graphql.NewLoader(graphql.PerKey(func(ctx context.Context, chargeID string) ([]*stripe.Refund, error) {
+			return a.refundService.ListRefunds(ctx, chargeID, stripe.Page{Limit: stripe.MaxPageLimit})
+		}, concurrency), wait),
+		// Disputes are read from the local copy kept in sync by webhooks
+		disputes: graphql.NewLoader(graphql.PerKey(func(ctx context.Context, chargeID string) ([]*stripe.Dispute, error) {
+			return a.disputes.List(ctx, disputes.Filter{ChargeID: chargeID})
+		}, concurrency), wait),
+	}
+}
+
+// loadersFrom returns the loaders of the request a resolver runs for
+func loadersFrom(ctx context.Context) *graphqlLoaders {
+	return ctx.Value(graphqlLoadersKey{}).(*graphqlLoaders)
+}
+
+// handleGraphQL handles a GraphQL query. Field errors are returned in the
+// response's errors alongside the data, as GraphQL clients expect.
+func (a *App) handleGraphQL(c *fiber.Ctx) error {
+	var request graphql.Request
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+	if request.Query == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Query is required", i18n.KeyMissingParameter)
+	}
+
+	ctx := context.WithValue(c.Context(), graphqlLoadersKey{}, a.newGraphQLLoaders())
+	response := a.graphqlSchema.Execute(ctx, request)
+	if response.Data == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(response)
+	}
+
+	return c.JSON(response)
+}
+
+// buildGraphQLSchema describes the resources queries can start from and how
+// they link to each other
+func (a *App) buildGraphQLSchema() *graphql.Schema {
+	address := &graphql.Object{Name: "Address", Fields: graphql.Scalars("line1", "line2", "city", "state", "postalCode", "country")}
+	card := &graphql.Object{Name: "Card", Fields: graphql.Scalars("last4", "brand", "expMonth", "expYear", "fingerprint")}
+	bankAccount := &graphql.Object{Name: "BankAccount", Fields: graphql.Scalars("bankName", "last4", "country", "accountHolderType", "accountType")}
+
+	customer := &graphql.Object{Name: "Customer", Fields: graphql.Scalars("id", "email", "name", "phone", "description", "metadata", "created", "updated")}
+	paymentMethod := &graphql.Object{Name: "PaymentMethod", Fields: graphql.Scalars("id", "token", "type", "wallet", "metadata", "created")}
+	charge := &graphql.Object{Name: "Charge", Fields: graphql.Scalars(
+		"id", "amount", "amountDecimal", "amountDisplay", "amountRefunded", "amountCaptured", "currency", "status",
+		"captured", "refunded", "disputed", "paymentMethodId", "paymentMethodType", "failureCode", "failureMessage",
+		"invoiceId", "description", "metadata", "riskLevel", "wallet", "created",
+	)}
+	refund := &graphql.Object{Name: "Refund", Fields: graphql.Scalars("id", "chargeId", "amount", "amountDecimal", "currency", "status", "reason", "metadata", "createdAt")}
+	subscription := &graphql.Object{Name: "Subscription", Fields: graphql.Scalars(
+		"id", "priceId", "quantity", "status", "paused", "cancelAtPeriodEnd", "currentPeriodStart", "currentPeriodEnd",
+		"collectionMethod", "paymentTerms", "daysUntilDue", "metadata", "created",
+	)}
+	dispute := &graphql.Object{Name: "Dispute", Fields: graphql.Scalars(
+		"id", "chargeId", "amount", "currency", "reason", "status", "networkReasonCode", "isChargeRefundable", "metadata", "created",
+	)}
+
+	customer.Fields["address"] = &graphql.Field{Type: address}
+	customer.Fields["paymentMethods"] = &graphql.Field{Type: paymentMethod, Resolve: func(ctx context.Context, source any, args graphql.Args) (any, error) {
+		limit, err := graphqlLimit(args)
+		if err != nil {
+			return nil, err
+		}
+		return loadersFrom(ctx).paymentMethods.Load(ctx, customerPage{source.(*stripe.Customer).ID, limit})
+	}}
+	customer.Fields["charges"] = &graphql.Field{Type: charge, Resolve: func(ctx context.Context, source any, args graphql.Args) (any, error) {
+		limit, err := graphqlLimit(args)
+		if err != nil {
+			return nil, err
+		}
+		return loadersFrom(ctx).customerCharges.Load(ctx, customerPage{source.(*stripe.Customer).ID, limit})
+	}}
+	customer.Fields["subscriptions"] = &graphql.Field{Type: subscription, Resolve: func(ctx context.Context, source any, args graphql.Args) (any, error) {
+		status := args.String("status")
+		if status == "" {
+			status = "all"
+		}
+		return loadersFrom(ctx).subscriptions.Load(ctx, customerSubscriptions{source.(*stripe.Customer).ID, status})
+	}}
+	customer.Fields["activeSubscription"] = &graphql.Field{Type: subscription, Resolve: func(ctx context.Context, source any, args graphql.Args) (any, error) {
+		active, err := loadersFrom(ctx).subscriptions.Load(ctx, customerSubscriptions{customerID: source.(*stripe.Customer).ID})
+		if err != nil || len(active) == 0 {
+			return nil, err
+		}
+		return active[0], nil
+	}}
+	customer.Fields["disputes"] = &graphql.Field{Type: dispute, Resolve: func(ctx context.Context, source any, args graphql.Args) (any, error) {
+		return customerDisputes(ctx, source.(*stripe.Customer).ID, args.Bool("open"))
+	}}
+
+	paymentMethod.Fields["card"] = &graphql.Field{Type: card}
+	paymentMethod.Fields["bankAccount"] = &graphql.Field{Type: bankAccount}
+	paymentMethod.Fields["customer"] = &graphql.Field{Type: customer, Resolve: func(ctx context.Context, source any, args graphql.Args) (any, error) {
+		return loadCustomer(ctx, source.(*vaultedPaymentMethod).Customer)
+	}}
+
+	charge.Fields["customer"] = &graphql.Field{Type: customer, Resolve: func(ctx context.Context, source any, args graphql.Args) (any, error) {
+		return loadCustomer(ctx, source.(*stripe.Charge).CustomerID)
+	}}
+	charge.Fields["refunds"] = &graphql.Field{Type: refund, Resolve: func(ctx context.Context, source any, args graphql.Args) (any, error) {
+		return loadersFrom(ctx).refunds.Load(ctx, source.(*stripe.Charge).ID)
+	}}
+	charge.Fields["disputes"] = &graphql.Field{Type: dispute, Resolve: func(ctx context.Context, source any, args graphql.Args) (any, error) {
+		// Only disputed charges have disputes to look up
+		if !source.(*stripe.Charge).Disputed {
+			return []*stripe.Dispute{}, nil
+		}
+		return loadersFrom(ctx).disputes.Load(ctx, source.(*stripe.Charge).ID)
+	}}
+
+	subscription.Fields["customer"] = &graphql.Field{Type: customer, Resolve: func(ctx context.Context, source any, args graphql.Args) (any, error) {
+		return loadCustomer(ctx, source.(*stripe.Subscription).CustomerID)
+	}}
+
+	dispute.Fields["evidenceDueBy"] = &graphql.Field{Resolve: func(ctx context.Context, source any, args graphql.Args) (any, error) {
+		if details := source.(*stripe.Dispute).EvidenceDetails; details != nil {
+			return details.DueBy, nil
+		}
+		return nil, nil
+	}}
+	dispute.Fields["charge"] = &graphql.Field{Type: charge, Resolve: func(ctx context.Context, source any, args graphql.Args) (any, error) {
+		return loadersFrom(ctx).charges.Load(ctx, source.(*stripe.Dispute).ChargeID)
+	}}
+
+	query := &graphql.Object{Name: "Query", Fields: map[string]*graphql.Field{
+		"customer": {Type: customer, Resolve: func(ctx context.Context, source any, args graphql.Args) (any, error) {
+			return loadCustomer(ctx, args.String("id"))
+		}},
+		"charge": {Type: charge, Resolve: func(ctx context.Context, source any, args graphql.Args) (any, error) {
+			if args.String("id") == "" {
+				return nil, errors.New("id is required")
+			}
+			return loadersFrom(ctx).charges.Load(ctx, args.String("id"))
+		}},
+		"subscription": {Type: subscription, Resolve: func(ctx context.Context, source any, args graphql.Args) (any, error) {
+			if args.String("id") == "" {
+				return nil, errors.New("id is required")
+			}
+			return a.subscriptionService.GetSubscription(ctx, args.String("id"))
+		}},
+		"dispute": {Type: dispute, Resolve: func(ctx context.Context, source any, args graphql.Args) (any, error) {
+			if args.String("id") == "" {
+				return nil, errors.New("id is required")
+			}
+			return a.disputes.Get(ctx, args.String("id"))
+		}},
+	}}
+
+	return graphql.NewSchema(query, a.graphqlConfig)
+}
+
+// loadCustomer loads a customer by ID
+func loadCustomer(ctx context.Context, customerID string) (*stripe.Customer, error) {
+	if customerID == "" {
+		return nil, errors.New("id is required")
+	}
+	return loadersFrom(ctx).customers.Load(ctx, customerID)
+}
+
+// customerDisputes returns the disputes of a customer's most recent
+// charges, newest charge first, optionally only those still open
+func customerDisputes(ctx context.Context, customerID string, open bool) ([]*stripe.Dispute, error) {
+	loaders := loadersFrom(ctx)
+	charges, err := loaders.customerCharges.Load(ctx, customerPage{customerID, stripe.MaxPageLimit})
+	if err != nil {
+		return nil, err
+	}
+
+	var disputed []string
+	for _, charge := range charges {
+		if charge.Disputed {
+			disputed = append(disputed, charge.ID)
+		}
+	}
+	perCharge, err := loaders.disputes.LoadMany(ctx, disputed)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*stripe.Dispute{}
+	for _, chargeDisputes := range perCharge {
+		for _, dispute := range chargeDisputes {
+			if !open || disputes.IsOpen(dispute.Status) {
+				result = append(result, dispute)
+			}
+		}
+	}
+	return result, nil
+}
+
+// graphqlLimit reads the limit argument of a list field
+func graphqlLimit(args graphql.Args) (int, error) {
+	limit := args.Int("limit", graphqlListLimit)
+	if limit < 1 || limit > stripe.MaxPageLimit {
+		return 0, errGraphQLLimit
+	}
+	return limit, nil
+}
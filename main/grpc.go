@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"apis/payments/services"
+	"apis/payments/services/chargestate"
+	"apis/payments/services/customerbalance"
+	"apis/payments/services/fraud"
+	"apis/payments/services/grpcserver"
+	"apis/payments/services/metadata"
+	"apis/payments/services/refundguard"
+	"apis/payments/services/requestid"
+	"apis/payments/services/stripe"
+
+	"google.golang.org/grpc/peer"
+)
+
+// gatewayChargePolicy applies the checks REST charges go through to gRPC
+// charges, which reach any provider's gateway rather than Stripe's charge
+// service
+type gatewayChargePolicy struct {
+	app *App
+}
+
+// Admit checks a charge against customer holds, the tenant's budget, card
+// fingerprint blocks and fraud screening, then spends customer credit on it.
+// Charges screened for review are refused, since only REST charges can be
+// held for review.
+func (p *gatewayChargePolicy) Admit(ctx context.Context, tenantID string, request *services.CreateChargeRequest) (*customerbalance.Application, error) {
+	a := p.app
+
+	if request.CustomerID != "" {
+		if err := a.holdService.CheckCharge(ctx, request.CustomerID); err != nil {
+			return nil, err
+		}
+	}
+	if err := a.budgets.CheckCharge(ctx, tenantID, request.Currency, request.Amount); err != nil {
+		return nil, err
+	}
+
+	screening := p.screening(ctx, tenantID, request)
+	if _, err := a.fingerprints.Check(ctx, tenantID, screening.CardFingerprint, screening.CustomerID); err != nil {
+		return nil, err
+	}
+	if a.fraud.Enabled() {
+		assessment, err := a.fraud.Screen(ctx, screening)
+		if err != nil {
+			return nil, err
+		}
+		if assessment.Decision == fraud.DecisionReview {
+			return nil, grpcserver.ErrChargeNeedsReview
+		}
+	}
+
+	// Customer credit is spent before the payment method is charged
+	chargeRequest := &stripe.ChargeRequest{
+		Amount:        request.Amount,
+		Currency:      request.Currency,
+		CustomerID:    request.CustomerID,
+		Description:   request.Description,
+		PaymentMethod: request.PaymentMethodID,
+	}
+	application, err := a.customerBalances.Apply(ctx, tenantID, chargeRequest)
+	if err != nil || application == nil {
+		return nil, err
+	}
+
+	request.Amount = chargeRequest.Amount
+	if request.Metadata == nil {
+		request.Metadata = make(map[string]interface{})
+	}
+	for _, key := range []string{"balance_applied", "balance_transaction"} {
+		request.Metadata[key] = chargeRequest.Metadata[key]
+	}
+
+	return application, nil
+}
+
+// Record counts a created charge against the tenant's budget and links the
+// credit spent on it to the charge
+func (p *gatewayChargePolicy) Record(ctx context.Context, tenantID string, application *customerbalance.Application, charge *services.Charge) {
+	a := p.app
+
+	if err := a.budgets.RecordCharge(ctx, tenantID, charge.Currency, charge.Amount); err != nil {
+		requestid.Logf(ctx, "Failed to record budget spend for charge %s: %v", charge.ID, err)
+	}
+	if application != nil {
+		if err := a.customerBalances.Settle(ctx, application, charge.ID); err != nil {
+			requestid.Logf(ctx, "Failed to link balance transaction %s to charge %s: %v", application.Transaction.ID, charge.ID, err)
+		}
+	}
+}
+
+// Reverse returns the credit spent on a charge that failed
+func (p *gatewayChargePolicy) Reverse(ctx context.Context, application *customerbalance.Application) {
+	p.app.reverseCustomerBalance(ctx, application)
+}
+
+// screening describes a gRPC charge for fraud screening. The card
+// fingerprint is only looked up when screening or fingerprint checks are
+// enabled.
+func (p *gatewayChargePolicy) screening(ctx context.Context, tenantID string, request *services.CreateChargeRequest) *fraud.Screening {
+	a := p.app
+
+	screening := &fraud.Screening{
+		TenantID:   tenantID,
+		CustomerID: request.CustomerID,
+		Amount:     request.Amount,
+		Currency:   strings.ToLower(request.Currency),
+	}
+	if caller, ok := peer.FromContext(ctx); ok {
+		screening.IPAddress = caller.Addr.String()
+		if host, _, err := net.SplitHostPort(screening.IPAddress); err == nil {
+			screening.IPAddress = host
+		}
+	}
+
+	if (a.fraud.Enabled() || a.fingerprints.Enabled()) && request.PaymentMethodID != "" {
+		paymentMethod, err := a.customerService.GetPaymentMethod(ctx, request.PaymentMethodID)
+		if err != nil {
+			requestid.Logf(ctx, "Failed to look up card fingerprint for fraud screening: %v", err)
+		} else if paymentMethod.Card != nil {
+			screening.CardFingerprint = paymentMethod.Card.Fingerprint
+		}
+	}
+
+	return screening
+}
+
+// refundPolicy issues gRPC refunds the way createRefund issues REST refunds
+type refundPolicy struct {
+	app *App
+}
+
+// CreateRefund checks the tenant's refund metadata schema and that the charge
+// can still be refunded, then issues the refund through the refund guard,
+// which may hold it for approval instead
+func (p *refundPolicy) CreateRefund(ctx context.Context, actor refundguard.Actor, request services.CreateRefundRequest) (*services.Refund, *refundguard.Approval, error) {
+	a := p.app
+
+	if err := a.metadataSchemas.Validate(ctx, actor.TenantID, metadata.ResourceRefund, nil); err != nil {
+		return nil, nil, err
+	}
+	err := a.chargeStates.Allows(ctx, request.ChargeID, chargestate.StatePartiallyRefunded, chargestate.StateRefunded)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	refund, approval, err := a.refundGuard.CreateRefund(ctx, actor, &stripe.RefundRequest{
+		ChargeID: request.ChargeID,
+		Amount:   request.Amount,
+		Reason:   request.Reason,
+	})
+	if err != nil || approval != nil {
+		return nil, approval, err
+	}
+	a.chargeCache.Invalidate(ctx, request.ChargeID)
+
+	gatewayMetadata := make(map[string]interface{}, len(refund.Metadata))
+	for key, value := range refund.Metadata {
+		gatewayMetadata[key] = value
+	}
+	return &services.Refund{
+		ID:         refund.ID,
+		ChargeID:   refund.ChargeID,
+		Amount:     refund.Amount,
+		Currency:   refund.Currency,
+		Reason:     refund.Reason,
+		Status:     refund.Status,
+		Metadata:   gatewayMetadata,
+		CreatedAt:  refund.CreatedAt,
+		UpdatedAt:  refund.UpdatedAt,
+		ProviderID: refund.ID,
+		Provider:   "stripe",
+	}, nil, nil
+}
+
+// disputePolicy answers gRPC disputes through the evidence service REST
+// dispute routes use
+type disputePolicy struct {
+	app *App
+}
+
+// StageEvidence merges evidence fields into a dispute's staged evidence
+func (p *disputePolicy) StageEvidence(ctx context.Context, disputeID string, fields map[string]string, operatorID string) error {
+	_, err := p.app.disputeEvidence.Update(ctx, disputeID, fields, operatorID)
+	return err
+}
+
+// SubmitEvidence submits a dispute's staged evidence to the bank
+func (p *disputePolicy) SubmitEvidence(ctx context.Context, disputeID, operatorID string) error {
+	_, err := p.app.disputeEvidence.Submit(ctx, disputeID, operatorID)
+	return err
+}
+
+// AcceptDispute concedes a dispute that still awaits a response
+func (p *disputePolicy) AcceptDispute(ctx context.Context, disputeID, operatorID string) error {
+	_, err := p.app.disputeEvidence.Accept(ctx, disputeID, operatorID)
+	return err
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	"apis/payments/services/history"
+	"apis/payments/services/i18n"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// entityHistory returns a handler for an entity's version history. With
+// ?as_of=<RFC 3339 time> it returns the single version current at that time.
+func (a *App) entityHistory(entityType string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		entityID := c.Params("id")
+		if entityID == "" {
+			return a.errorMessage(c, fiber.StatusBadRequest, "ID is required", i18n.KeyMissingParameter)
+		}
+
+		if raw := c.Query("as_of"); raw != "" {
+			asOf, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return a.errorMessage(c, fiber.StatusBadRequest, "as_of must be an RFC 3339 timestamp", i18n.KeyInvalidRequest)
+			}
+
+			version, err := a.historyService.AsOf(c.Context(), entityType, entityID, asOf)
+			if errors.Is(err, history.ErrNoVersion) {
+				return a.errorResponse(c, fiber.StatusNotFound, err)
+			}
+			if err != nil {
+				return a.errorResponse(c, fiber.StatusInternalServerError, err)
+			}
+
+			return c.JSON(version)
+		}
+
+		versions, err := a.historyService.History(c.Context(), entityType, entityID)
+		if err != nil {
+			return a.errorResponse(c, fiber.StatusInternalServerError, err)
+		}
+
+		return c.JSON(versions)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+
+	"apis/payments/services/blocklist"
+	"apis/payments/services/fingerprints"
+	"apis/payments/services/fraud"
+	"apis/payments/services/holds"
+	"apis/payments/services/i18n"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// getHoldPolicy handles hold policy retrieval
+func (a *App) getHoldPolicy(c *fiber.Ctx) error {
+	tenantID := c.Params("tenantId")
+	if tenantID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Tenant ID is required", i18n.KeyMissingParameter)
+	}
+
+	policy, err := a.holdService.GetPolicy(c.Context(), tenantID)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(policy)
+}
+
+// updateHoldPolicy handles hold policy updates
+func (a *App) updateHoldPolicy(c *fiber.Ctx) error {
+	tenantID := c.Params("tenantId")
+	if tenantID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Tenant ID is required", i18n.KeyMissingParameter)
+	}
+
+	var policy holds.Policy
+	if err := c.BodyParser(&policy); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	// Set the tenant ID from the URL parameter
+	policy.TenantID = tenantID
+
+	updated, err := a.holdService.UpdatePolicy(c.Context(), &policy)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	return c.JSON(updated)
+}
+
+// listCustomerHolds handles listing holds for a customer
+func (a *App) listCustomerHolds(c *fiber.Ctx) error {
+	customerID := c.Params("customerId")
+	if customerID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Customer ID is required", i18n.KeyMissingParameter)
+	}
+
+	customerHolds, err := a.holdService.ListHolds(c.Context(), customerID)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	return c.JSON(customerHolds)
+}
+
+// releaseHold handles manually releasing a customer hold
+func (a *App) releaseHold(c *fiber.Ctx) error {
+	holdID := c.Params("id")
+	if holdID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Hold ID is required", i18n.KeyMissingParameter)
+	}
+
+	var request struct {
+		Reason string `json:"reason"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+		}
+	}
+	if request.Reason == "" {
+		request.Reason = "manual"
+	}
+
+	hold, err := a.holdService.ReleaseHold(c.Context(), holdID, request.Reason)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	return c.JSON(hold)
+}
+
+// chargeErrorStatus maps charge creation errors to HTTP status codes
+func chargeErrorStatus(err error) int {
+	if errors.Is(err, holds.ErrCustomerOnHold) || errors.Is(err, blocklist.ErrBlocked) ||
+		errors.Is(err, fraud.ErrRejected) || errors.Is(err, fingerprints.ErrBlocked) {
+		return fiber.StatusForbidden
+	}
+	return fiber.StatusBadRequest
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"apis/payments/services/i18n"
+	"apis/payments/services/invoicing"
+	"apis/payments/services/stripe"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// createInvoicedSubscription handles creating a subscription billed by invoice with payment terms
+func (a *App) createInvoicedSubscription(c *fiber.Ctx) error {
+	var request struct {
+		stripe.InvoicedSubscriptionRequest
+		CustomFields map[string]string `json:"custom_fields,omitempty"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	if c.QueryBool("dry_run") {
+		return a.dryRunInvoicedSubscription(c, &request.InvoicedSubscriptionRequest, request.CustomFields)
+	}
+
+	// Custom field values are kept in subscription metadata and printed on
+	// each invoice as it is created
+	fieldMetadata, _, err := a.collectCustomFields(c, request.Metadata, request.CustomFields)
+	if err != nil {
+		return a.errorResponse(c, customFieldsErrorStatus(err), err)
+	}
+	request.Metadata = fieldMetadata
+
+	subscription, err := a.subscriptionService.CreateInvoicedSubscription(c.Context(), &request.InvoicedSubscriptionRequest)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(subscription)
+}
+
+// updatePaymentTerms handles changing the payment terms of an invoiced subscription
+func (a *App) updatePaymentTerms(c *fiber.Ctx) error {
+	subscriptionID := c.Params("id")
+	if subscriptionID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Subscription ID is required", i18n.KeyMissingParameter)
+	}
+
+	var request struct {
+		Terms string `json:"terms"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	subscription, err := a.subscriptionService.SetPaymentTerms(c.Context(), subscriptionID, request.Terms)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	return c.JSON(subscription)
+}
+
+// listOverdueInvoices handles listing open invoices past their due date
+func (a *App) listOverdueInvoices(c *fiber.Ctx) error {
+	invoices, err := a.invoicing.ListOverdue(c.Context(), c.Query("customer_id"))
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	now := time.Now()
+	overdue := make([]fiber.Map, len(invoices))
+	for i, invoice := range invoices {
+		overdue[i] = fiber.Map{
+			"invoice":      invoice,
+			"days_overdue": invoice.DaysOverdue(now),
+		}
+	}
+
+	return c.JSON(overdue)
+}
+
+// markInvoicePaid handles recording an offline bank transfer against an invoice
+func (a *App) markInvoicePaid(c *fiber.Ctx) error {
+	invoiceID := c.Params("id")
+	if invoiceID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invoice ID is required", i18n.KeyMissingParameter)
+	}
+
+	var request invoicing.MarkPaidRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	invoice, err := a.invoicing.MarkPaid(c.Context(), invoiceID, &request)
+	if err != nil {
+		status := fiber.StatusBadRequest
+		if errors.Is(err, sql.ErrNoRows) {
+			status = fiber.StatusNotFound
+		} else if errors.Is(err, invoicing.ErrInvoiceNotOpen) {
+			status = fiber.StatusConflict
+		}
+		return a.errorResponse(c, status, err)
+	}
+
+	return c.JSON(invoice)
+}
+
+// sendInvoiceReminders handles running invoice reminders immediately
+func (a *App) sendInvoiceReminders(c *fiber.Ctx) error {
+	sent, err := a.invoicing.SendReminders(c.Context(), time.Now())
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"reminders_sent": sent,
+	})
+}
+
+// invoiceErrorStatus maps invoice errors to HTTP status codes. Provider
+// errors, such as paying a voided invoice, are the caller's to fix.
+func invoiceErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return fiber.StatusNotFound
+	case errors.Is(err, invoicing.ErrPDFUnavailable):
+		return fiber.StatusConflict
+	default:
+		return fiber.StatusBadRequest
+	}
+}
+
+// listInvoices handles listing stored invoices, optionally for one customer
+// and in one status
+func (a *App) listInvoices(c *fiber.Ctx) error {
+	invoices, err := a.invoicing.List(c.Context(), invoicing.InvoiceFilter{
+		CustomerID: c.Query("customer_id"),
+		Status:     c.Query("status"),
+		Limit:      c.QueryInt("limit"),
+	})
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(fiber.Map{"data": invoices})
+}
+
+// createInvoice handles creating a draft invoice from line items
+func (a *App) createInvoice(c *fiber.Ctx) error {
+	var request stripe.CreateInvoiceRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	invoice, err := a.invoicing.Create(c.Context(), &request)
+	if err != nil {
+		return a.errorResponse(c, invoiceErrorStatus(err), err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(invoice)
+}
+
+// getInvoice handles retrieving an invoice
+func (a *App) getInvoice(c *fiber.Ctx) error {
+	invoice, err := a.invoicing.Get(c.Context(), c.Params("id"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return a.errorMessage(c, fiber.StatusNotFound, "Invoice not found", i18n.KeyNotFound)
+	}
+	if err != nil {
+		return a.errorResponse(c, invoiceErrorStatus(err), err)
+	}
+
+	return c.JSON(invoice)
+}
+
+// finalizeInvoice handles finalizing a draft invoice
+func (a *App) finalizeInvoice(c *fiber.Ctx) error {
+	invoice, err := a.invoicing.Finalize(c.Context(), c.Params("id"))
+	if err != nil {
+		return a.errorResponse(c, invoiceErrorStatus(err), err)
+	}
+
+	return c.JSON(invoice)
+}
+
+// payInvoice handles paying an open invoice now
+func (a *App) payInvoice(c *fiber.Ctx) error {
+	var request struct {
+		PaymentMethodID string `json:"payment_method_id,omitempty"` // Defaults to the customer's default
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+		}
+	}
+
+	invoice, err := a.invoicing.Pay(c.Context(), c.Params("id"), request.PaymentMethodID)
+	if err != nil {
+		return a.errorResponse(c, invoiceErrorStatus(err), err)
+	}
+
+	return c.JSON(invoice)
+}
+
+// voidInvoice handles voiding an open invoice
+func (a *App) voidInvoice(c *fiber.Ctx) error {
+	invoice, err := a.invoicing.Void(c.Context(), c.Params("id"))
+	if err != nil {
+		return a.errorResponse(c, invoiceErrorStatus(err), err)
+	}
+
+	return c.JSON(invoice)
+}
+
+// getInvoicePDF handles retrieving the download URL of a finalized invoice's PDF
+func (a *App) getInvoicePDF(c *fiber.Ctx) error {
+	invoiceID := c.Params("id")
+	url, err := a.invoicing.PDFURL(c.Context(), invoiceID)
+	if err != nil {
+		return a.errorResponse(c, invoiceErrorStatus(err), err)
+	}
+
+	return c.JSON(fiber.Map{
+		"invoice_id": invoiceID,
+		"url":        url,
+	})
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+
+	"apis/payments/services/jobs"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// jobsErrorStatus maps job scheduler errors to HTTP statuses
+func jobsErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, jobs.ErrJobNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, jobs.ErrJobRunning):
+		return fiber.StatusConflict
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// listJobs handles listing the scheduled jobs with their latest run
+func (a *App) listJobs(c *fiber.Ctx) error {
+	statuses, err := a.jobs.Jobs(c.Context())
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(fiber.Map{"data": statuses})
+}
+
+// listJobRuns handles listing a job's runs across instances, newest first
+func (a *App) listJobRuns(c *fiber.Ctx) error {
+	runs, err := a.jobs.Runs(c.Context(), c.Params("name"), c.QueryInt("limit"))
+	if err != nil {
+		return a.errorResponse(c, jobsErrorStatus(err), err)
+	}
+
+	return c.JSON(fiber.Map{"data": runs})
+}
+
+// runJob handles running a job immediately. The job runs in the background;
+// the run is returned so it can be followed in the job's run history.
+func (a *App) runJob(c *fiber.Ctx) error {
+	run, err := a.jobs.RunNow(c.Context(), c.Params("name"))
+	if err != nil {
+		return a.errorResponse(c, jobsErrorStatus(err), err)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(run)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	"apis/payments/services/i18n"
+	"apis/payments/services/ledger"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// listLedgerAccounts handles listing the ledger accounts and their types
+func (a *App) listLedgerAccounts(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"data": ledger.Accounts()})
+}
+
+// listLedgerAccountEntries handles listing the entries posted to a ledger
+// account, newest first
+func (a *App) listLedgerAccountEntries(c *fiber.Ctx) error {
+	entries, err := a.ledger.AccountEntries(c.Context(), c.Params("id"), c.Query("currency"), c.QueryInt("limit"), c.QueryInt("offset"))
+	if errors.Is(err, ledger.ErrUnknownAccount) {
+		return a.errorMessage(c, fiber.StatusNotFound, "Ledger account not found", i18n.KeyNotFound)
+	}
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(fiber.Map{"data": entries})
+}
+
+// getTrialBalance handles totalling every ledger account per currency. With
+// ?as_of=<RFC 3339 time> only entries posted by then are included.
+func (a *App) getTrialBalance(c *fiber.Ctx) error {
+	asOf := time.Now().UTC()
+	if raw := c.Query("as_of"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return a.errorMessage(c, fiber.StatusBadRequest, "as_of must be an RFC 3339 timestamp", i18n.KeyInvalidRequest)
+		}
+		asOf = parsed
+	}
+
+	balances, err := a.ledger.TrialBalance(c.Context(), asOf)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(fiber.Map{"data": balances})
+}
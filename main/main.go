@@ -2,14 +2,94 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"os"
 	"os/signal"
+	"runtime/trace"
 	"syscall"
 	"time"
 
+	"apis/payments/db"
+	"apis/payments/db/clickhouse"
+	"apis/payments/services"
+	"apis/payments/services/alerts"
+	"apis/payments/services/analytics"
+	"apis/payments/services/audit"
+	"apis/payments/services/auth"
+	"apis/payments/services/authorizations"
+	"apis/payments/services/autorefund"
+	"apis/payments/services/backpressure"
+	"apis/payments/services/bankdebits"
+	"apis/payments/services/batching"
+	"apis/payments/services/blocklist"
+	"apis/payments/services/budgets"
+	"apis/payments/services/cache"
+	"apis/payments/services/cardupdates"
+	"apis/payments/services/chargesearch"
+	"apis/payments/services/chargestate"
+	"apis/payments/services/commands"
+	"apis/payments/services/composite"
+	"apis/payments/services/config"
+	"apis/payments/services/customerbalance"
+	"apis/payments/services/customers"
+	"apis/payments/services/customerstats"
+	"apis/payments/services/customfields"
+	"apis/payments/services/deadletter"
+	"apis/payments/services/deprecation"
+	"apis/payments/services/disputes"
+	"apis/payments/services/documents"
+	"apis/payments/services/drain"
+	"apis/payments/services/dryrun"
+	"apis/payments/services/dunning"
+	"apis/payments/services/ephemeralkeys"
+	"apis/payments/services/eventarchive"
+	"apis/payments/services/events"
+	"apis/payments/services/fingerprints"
+	"apis/payments/services/fraud"
+	"apis/payments/services/fx"
+	"apis/payments/services/graphql"
+	"apis/payments/services/grpcserver"
+	"apis/payments/services/history"
+	"apis/payments/services/holds"
+	"apis/payments/services/i18n"
+	"apis/payments/services/instrumentation"
+	"apis/payments/services/invoicing"
+	"apis/payments/services/jobs"
+	"apis/payments/services/kafka"
+	"apis/payments/services/ledger"
+	"apis/payments/services/merchantwebhooks"
+	"apis/payments/services/metadata"
+	"apis/payments/services/mirror"
+	"apis/payments/services/money"
+	"apis/payments/services/offboarding"
+	"apis/payments/services/openapi"
+	"apis/payments/services/paymentlinks"
+	"apis/payments/services/paymentmethods"
+	"apis/payments/services/projections"
+	"apis/payments/services/quarantine"
+	"apis/payments/services/ratelimit"
+	"apis/payments/services/receipts"
+	"apis/payments/services/reconciliation"
+	"apis/payments/services/redis"
+	"apis/payments/services/refundbatches"
+	"apis/payments/services/refundguard"
+	"apis/payments/services/relay"
+	"apis/payments/services/requestid"
+	"apis/payments/services/resilience"
+	"apis/payments/services/routing"
+	"apis/payments/services/runmode"
+	"apis/payments/services/simulator"
+	"apis/payments/services/smartrouting"
 	"apis/payments/services/stripe"
+	"apis/payments/services/subscriptions"
+	"apis/payments/services/tenancy"
+	"apis/payments/services/tenantcredentials"
+	"apis/payments/services/usage"
+	"apis/payments/services/vault"
+	"apis/payments/services/webhooksecrets"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -20,22 +100,624 @@ import (
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"google.golang.org/grpc"
 )
 
 // App represents the main application
 type App struct {
-	fiberApp        *fiber.App
-	customerService *stripe.CustomerService
-	chargeService   *stripe.ChargeService
-	refundService   *stripe.RefundService
+	fiberApp            *fiber.App
+	adminApp            *fiber.App
+	grpcServer          *grpc.Server
+	grpcConfig          *grpcserver.Config
+	apiSpec             *openapi.Document
+	connectionManager   *db.ConnectionManager
+	repository          *db.Repository
+	customerService     *stripe.CustomerService
+	chargeService       *stripe.ChargeService
+	customerCache       *cache.Reader[stripe.Customer]
+	chargeCache         *cache.Reader[stripe.Charge]
+	cacheMetrics        *cache.Metrics
+	wallets             *stripe.WalletService
+	refundService       *stripe.RefundService
+	subscriptionService *stripe.SubscriptionService
+	subscriptions       *subscriptions.Service
+	usage               *usage.Service
+	connectService      *stripe.ConnectService
+	webhookService      *stripe.WebhookService
+	paddleWebhooks      *services.PaddleGateway
+	paypalWebhooks      *services.PayPalGateway
+	holdService         *holds.Service
+	refundGuard         *refundguard.Service
+	translator          *i18n.Translator
+	gatewayRecorder     *instrumentation.Recorder
+	eventSource         *events.Source
+	metadataSchemas     *metadata.Service
+	customFields        *customfields.Service
+	composite           *composite.Service
+	projections         *projections.Service
+	historyService      *history.Service
+	deprecations        *deprecation.Service
+	autoRefunds         *autorefund.Service
+	authorizations      *authorizations.Service
+	invoicing           *invoicing.Service
+	dunning             *dunning.Service
+	vault               *vault.Service
+	router              *routing.Router
+	smartRouting        *smartrouting.Service
+	disputes            *disputes.Service
+	disputeEvidence     *disputes.EvidenceService
+	ephemeralKeys       *ephemeralkeys.Service
+	auth                *auth.Service
+	budgets             *budgets.Service
+	drain               *drain.Tracker
+	customerIdentities  *customers.Service
+	customerUpserts     *customers.Upserter
+	customerSearch      *customers.Searcher
+	customerLifecycle   *customers.Lifecycle
+	auditLog            *audit.Service
+	chargeSearch        *chargesearch.Service
+	customerStats       *customerstats.Service
+	dryRunConfig        *dryrun.Config
+	drainConfig         *drain.Config
+	radar               *stripe.RadarService
+	blocklist           *blocklist.Service
+	fraud               *fraud.Service
+	fingerprints        *fingerprints.Service
+	chargeStates        *chargestate.Service
+	quarantine          *quarantine.Service
+	replayer            *requestReplayer
+	backpressure        *backpressure.Monitor
+	rateLimits          *ratelimit.Service
+	runMode             runmode.Mode
+	commands            *commands.Service
+	kafkaConfig         *kafka.Config
+	eventPublisher      *kafka.Publisher
+	providerCredentials *tenantcredentials.Service
+	tenancy             *tenancy.Service
+	tenantGateways      *services.TenantGateways
+	providers           *services.EnabledProviders
+	gatewayResilience   *resilience.Policy
+	webhookSecrets      *webhooksecrets.Service
+	deadLetters         *deadletter.Service
+	merchantWebhooks    *merchantwebhooks.Service
+	relayOutbox         *relay.Outbox
+	eventArchive        *eventarchive.Service
+	receipts            *receipts.Service
+	documents           *documents.Service
+	paymentMethods      *paymentmethods.Service
+	customerBalances    *customerbalance.Service
+	offboarding         *offboarding.Service
+	refundBatches       *refundbatches.Service
+	graphqlConfig       *graphql.Config
+	graphqlSchema       *graphql.Schema
+	analyticsWriter     *clickhouse.Writer
+	analyticsConfig     *clickhouse.Config
+	analyticsIngester   *clickhouse.Ingester
+	analytics           *analytics.Service
+	paymentLinks        *paymentlinks.Service
+	fx                  *fx.Service
+	ledger              *ledger.Service
+	reconciliation      *reconciliation.Service
+	jobs                *jobs.Scheduler
+	config              *config.Loader
+	simulator           *services.SimulatorGateway
 }
 
 // NewApp creates a new application instance
-func NewApp() *App {
+func NewApp(loader *config.Loader) *App {
+	// The same binary runs as the API tier, the worker tier or both
+	runMode, err := runmode.Load()
+	if err != nil {
+		log.Fatalf("Failed to configure run mode: %v", err)
+	}
+
+	// Measure every provider call and log slow ones
+	gatewayRecorder, err := configureGateway()
+	if err != nil {
+		log.Fatalf("Failed to configure gateway: %v", err)
+	}
+
+	// CloudEvents source identifying this deployment
+	eventSource, err := events.LoadSource()
+	if err != nil {
+		log.Fatalf("Failed to configure event source: %v", err)
+	}
+
 	// Initialize services
 	customerService := stripe.NewCustomerService()
 	chargeService := stripe.NewChargeService()
 	refundService := stripe.NewRefundService()
+	subscriptionService := stripe.NewSubscriptionService()
+	webhookService := stripe.NewWebhookService(os.Getenv("STRIPE_WEBHOOK_SECRET"))
+
+	// Connect to the database
+	connectionManager := db.NewConnectionManager()
+	if err := connectionManager.ConnectYugabyte(context.Background(), db.LoadYugabyteConfig()); err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	repository := db.NewRepository(connectionManager.GetYugabytePool())
+	webhookService.UseEventLog(repository)
+	chargeService.UseCredentialLog(repository)
+
+	// Every provider object is kept locally, from our own mutations and provider events
+	mirrorService := mirror.NewService(repository)
+	customerService.UseMirror(mirrorService)
+	chargeService.UseMirror(mirrorService)
+	refundService.UseMirror(mirrorService)
+	subscriptionService.UseMirror(mirrorService)
+	mirrorService.RegisterWebhookHandlers(webhookService)
+
+	// Customer and charge reads are cached in Redis when enabled, and dropped
+	// from the cache when they change
+	cacheConfig := cache.LoadConfig()
+	cacheMetrics := cache.NewMetrics()
+	var cacheStore cache.Store
+	if cacheConfig.Enabled {
+		redisConfig, err := redis.LoadConfig()
+		if err != nil {
+			log.Fatalf("Failed to configure redis: %v", err)
+		}
+		if redisConfig == nil {
+			log.Fatalf("CACHE_ENABLED=true requires REDIS_URL")
+		}
+		cacheStore = cache.NewRedisStore(redis.NewClient(redisConfig))
+	}
+	customerCache := cache.NewReader(cacheStore, cacheMetrics, cache.KindCustomer, cacheConfig.CustomerTTL, customerService.GetCustomer)
+	chargeCache := cache.NewReader(cacheStore, cacheMetrics, cache.KindCharge, cacheConfig.ChargeTTL, chargeService.GetCharge)
+	cache.RegisterWebhookHandlers(webhookService, customerCache, chargeCache, repository)
+
+	// Customer holds pause subscriptions and block charges on disputes and fraud flags
+	holdService := holds.NewService(repository, subscriptionService)
+	chargeService.AddChargeGuard(holdService)
+	holdService.RegisterWebhookHandlers(webhookService, chargeService)
+
+	// Blocked emails and card fingerprints are mirrored to Radar value lists
+	radarService := stripe.NewRadarService()
+	blocklistConfig := blocklist.LoadConfig()
+	blocklistService := blocklist.NewService(repository, newRadarMirror(radarService, blocklistConfig.Lists), customerService, blocklistConfig)
+	chargeService.AddChargeGuard(blocklistService)
+
+	// Scheduled plan changes are applied by Stripe at period end
+	subscriptionService.RegisterWebhookHandlers(webhookService)
+
+	// Refund velocity limits hold anomalous refunds for step-up approval
+	var refundAlerter refundguard.Alerter = alerts.LogAlerter[*refundguard.Alert]{}
+	if url := os.Getenv("REFUND_ALERT_WEBHOOK_URL"); url != "" {
+		refundAlerter = alerts.MultiAlerter[*refundguard.Alert]{refundAlerter, alerts.NewWebhookAlerter[*refundguard.Alert](url)}
+	}
+	refundGuard := refundguard.NewService(repository, refundService, chargeService, refundAlerter, refundguard.LoadLimits())
+
+	// Denormalized charge list rows are maintained from provider events
+	projectionService := projections.NewService(repository, chargeService, customerService, refundService, subscriptionService)
+	projectionService.UseBatchLimits(batching.LoadLimits("PROJECTION_REBUILD"))
+	projectionService.RegisterWebhookHandlers(webhookService)
+
+	// Every charge, subscription and dispute state change is kept as a version
+	historyService := history.NewService(repository)
+	historyService.RegisterWebhookHandlers(webhookService)
+
+	// Disputes are kept in full, synced from dispute events
+	disputeService := disputes.NewService(repository, stripe.NewDisputeService())
+	disputeService.RegisterWebhookHandlers(webhookService)
+
+	// Tenant provider credentials and webhook endpoint secrets are encrypted
+	// at rest with the same key
+	credentialCipher, err := tenantcredentials.LoadCipher()
+	if errors.Is(err, tenantcredentials.ErrEncryptionNotConfigured) {
+		log.Printf("Warning: CREDENTIALS_ENCRYPTION_KEY is not set; tenant provider credentials and webhook endpoints cannot be saved")
+	} else if err != nil {
+		log.Fatalf("Failed to configure credential encryption: %v", err)
+	}
+
+	// Events are published to Kafka when enabled and logged otherwise, and
+	// queued for the webhook endpoints of the tenant they were emitted for
+	kafkaConfig := kafka.LoadConfig()
+	var publisher events.Publisher = events.LogPublisher{}
+	var eventPublisher *kafka.Publisher
+	if kafkaConfig.EventsEnabled {
+		eventPublisher, err = kafka.NewPublisher(kafkaConfig)
+		if err != nil {
+			log.Fatalf("Failed to connect event publisher: %v", err)
+		}
+		publisher = eventPublisher
+	}
+	merchantWebhooks := merchantwebhooks.NewService(repository, credentialCipher, merchantwebhooks.LoadConfig())
+	eventPublishers := events.MultiPublisher{publisher, merchantWebhooks}
+
+	// Emitted events are archived so they can be replayed, to Kafka topics
+	// when events are published there
+	var eventTopics eventarchive.TopicPublisher
+	if eventPublisher != nil {
+		eventTopics = eventPublisher
+	}
+	eventArchive := eventarchive.NewService(repository, eventTopics, merchantWebhooks, eventarchive.LoadConfig())
+	eventArchive.UseBatchLimits(batching.LoadLimits("EVENT_REPLAY"))
+	if eventArchive.Enabled() {
+		eventPublishers = append(eventPublishers, eventArchive)
+	}
+	emitter := events.NewEmitter(eventSource, eventPublishers)
+
+	// Relayed provider events are stored and published by workers in adaptive
+	// batches when the outbox is enabled
+	relayOutbox := relay.NewOutbox(repository, eventPublishers, relay.LoadOutboxConfig())
+	relayOutbox.UseBatchLimits(batching.LoadLimits("RELAY_OUTBOX"))
+	relayEmitter := emitter
+	if relayOutbox.Enabled() {
+		relayEmitter = events.NewEmitter(eventSource, relayOutbox)
+	}
+
+	// Refund policies reject refunds and hold large ones for approval; every
+	// held refund is announced so approvers can be notified
+	refundGuard.UsePolicy(refundguard.LoadPolicy())
+	refundGuard.UseEmitter(emitter)
+
+	// Dispute evidence is staged locally, submitted through the provider, and
+	// reminded about as deadlines approach
+	disputeEvidence := disputes.NewEvidenceService(repository, disputeService, stripe.NewDisputeService(), emitter, disputes.LoadConfig())
+
+	// Subscription lifecycle changes made through the API are published as events
+	subscriptionLifecycle := subscriptions.NewService(subscriptionService, emitter)
+
+	// Metered usage is kept locally and pushed to the subscription's metered price
+	usageService := usage.NewService(repository, subscriptionService, usage.LoadConfig())
+	ledgerService := ledger.NewService(repository)
+
+	// Captured charges, refunds, disputes, payouts and provider fees are
+	// posted to the ledger as balanced entries
+	balanceService := stripe.NewBalanceService()
+	ledgerService.RegisterWebhookHandlers(webhookService, balanceService)
+
+	// Provider balance transactions and payouts are reconciled nightly
+	// against stored charges and refunds
+	reconciliationService := reconciliation.NewService(repository, balanceService, emitter, reconciliation.LoadConfig())
+
+	// Charge states move through validated transitions recorded from the API and webhooks
+	chargeStates := chargestate.NewService(repository, emitter)
+	chargeStates.RegisterWebhookHandlers(webhookService, chargeService)
+
+	// Invoices are kept locally, and those sent with NET terms are tracked
+	// until paid, with reminders
+	invoiceService := stripe.NewInvoiceService()
+	invoicingService := invoicing.NewService(repository, invoiceService, ledgerService, emitter, invoicing.LoadConfig())
+	invoicingService.RegisterWebhookHandlers(webhookService)
+
+	// Credits issued to customers are spent on their charges before the
+	// payment method, and on their invoices by Stripe
+	customerBalances := customerbalance.NewService(repository, customerService, chargeService)
+	customerBalances.RegisterWebhookHandlers(webhookService)
+
+	// Failed subscription invoice payments are retried on a schedule while
+	// the subscription moves through grace, past due, suspended and canceled
+	dunningService := dunning.NewService(repository, invoiceService, subscriptionService, emitter, dunning.LoadConfig())
+	dunningService.RegisterWebhookHandlers(webhookService)
+
+	// Payment link conversions are counted from completed checkouts, and links
+	// past their expiry are deactivated
+	paymentLinks := paymentlinks.NewService(repository, stripe.NewPaymentLinkService(), emitter, paymentlinks.LoadConfig())
+	paymentLinks.RegisterWebhookHandlers(webhookService)
+
+	// Charge, refund, dispute and invoice changes made at the provider are
+	// re-published once the handlers above have stored them
+	relayService := relay.NewService(relayEmitter)
+	relayService.RegisterWebhookHandlers(webhookService)
+	paddleWebhooks := newPaddleWebhooks(relayService)
+	paypalWebhooks := newPayPalWebhooks(relayService)
+
+	// Bank debits settle, and bank accounts are verified, days after they
+	// are made; their progress is published from the same webhooks
+	bankdebits.NewService(emitter).RegisterWebhookHandlers(webhookService)
+
+	// Customer stats are computed from mirrored charges and cached until they change
+	customerStats := customerstats.NewService(repository, customerstats.LoadConfig())
+	customerStats.RegisterWebhookHandlers(webhookService)
+
+	// Funds left unclaimed in customer cash balances are refunded after a window
+	autoRefunds := autorefund.NewService(repository, refundService, ledgerService, emitter, autorefund.LoadConfig())
+	autoRefunds.RegisterWebhookHandlers(webhookService)
+
+	// New customers are checked for duplicates and optionally email-verified
+	customersConfig := customers.LoadConfig()
+	customerIdentities := customers.NewService(repository, emitter, customersConfig)
+
+	// Callers reference payment methods by pmt_ tokens that map to provider tokens
+	vaultService := vault.NewService(repository)
+
+	// Cards refreshed by the card account updater, or edited, are announced
+	// and the customer's failed subscription payments retried on them
+	cardUpdates := cardupdates.NewService(dunningService, vaultService, emitter)
+	cardUpdates.RegisterWebhookHandlers(webhookService)
+
+	// Amounts are converted between currencies at cached provider rates
+	fxConfig := fx.LoadConfig()
+	fxProvider, err := fx.NewProvider(fxConfig)
+	if err != nil {
+		log.Fatalf("Failed to configure exchange rates: %v", err)
+	}
+	fxService := fx.NewService(fxProvider, fxConfig)
+
+	// Charges are routed by currency; only providers registered here take charges
+	router := routing.NewRouter(repository, routing.LoadConfig())
+	router.RegisterProvider(vaultProvider)
+
+	// ClickHouse stores the charge attempts smart routing measures and the
+	// analytics events. Its tables are created and updated on startup.
+	smartRoutingConfig := smartrouting.LoadConfig()
+	analyticsConfig := clickhouse.LoadConfig()
+	clickHouseReady := false
+	if smartRoutingConfig.Enabled || analyticsConfig.Enabled {
+		if err := connectionManager.ConnectClickHouse(context.Background(), db.LoadClickHouseConfig()); err != nil {
+			log.Printf("Warning: running without ClickHouse: %v", err)
+		} else {
+			if err := clickhouse.Migrate(context.Background(), connectionManager.GetClickHouse()); err != nil {
+				log.Fatalf("Failed to migrate ClickHouse: %v", err)
+			}
+			clickHouseReady = true
+		}
+	}
+
+	// Provider events are logged to ClickHouse in batches for analytics,
+	// and reported on over the analytics API. Charge, refund and dispute
+	// events can instead be ingested from the events topic they are relayed
+	// to, off the webhook path.
+	var analyticsWriter *clickhouse.Writer
+	var analyticsIngester *clickhouse.Ingester
+	var analyticsService *analytics.Service
+	if analyticsConfig.Enabled && clickHouseReady {
+		analyticsWriter = clickhouse.NewWriter(clickhouse.BatchInsert(connectionManager.GetClickHouse()), analyticsConfig)
+		analyticsStore := clickhouse.NewAnalyticsService(connectionManager.GetClickHouse(), analyticsWriter)
+		if analyticsConfig.FromKafka {
+			if !kafkaConfig.EventsEnabled {
+				log.Fatalf("ANALYTICS_FROM_KAFKA needs KAFKA_EVENTS_ENABLED, as events are only relayed to Kafka with it")
+			}
+			analyticsIngester = clickhouse.NewIngester(connectionManager.GetClickHouse(), clickhouse.BatchInsert(connectionManager.GetClickHouse()))
+			analyticsStore.SkipRelayedEvents()
+		}
+		analyticsStore.RegisterWebhookHandlers(webhookService)
+		analyticsService = analytics.NewService(analyticsStore)
+	}
+
+	// Smart routing ranks gateway charge providers by cost or authorization
+	// rate, measured over charge attempts stored in ClickHouse. Without
+	// ClickHouse, rates are unknown and providers are ranked by cost.
+	var routingStats smartrouting.Stats
+	if smartRoutingConfig.Enabled && clickHouseReady {
+		routingStats = clickhouse.NewRoutingStats(connectionManager.GetClickHouse())
+	}
+	smartRouting := smartrouting.NewService(repository, routingStats, smartRoutingConfig)
+	if smartRoutingConfig.Enabled {
+		services.GetFactory().UseRoutingPolicy(smartRouting)
+	}
+
+	// Monthly processing budgets per tenant, stated in the base reporting currency
+	var budgetAlerter budgets.Alerter = alerts.LogAlerter[*budgets.Alert]{}
+	if url := os.Getenv("BUDGET_ALERT_WEBHOOK_URL"); url != "" {
+		budgetAlerter = alerts.MultiAlerter[*budgets.Alert]{budgetAlerter, alerts.NewWebhookAlerter[*budgets.Alert](url)}
+	}
+	budgetService := budgets.NewService(repository, router, budgetAlerter)
+
+	// Offboarding exports package a tenant's data for a merchant leaving; PAN
+	// migrations are filed with the provider through the migration hook
+	var migrationHook offboarding.MigrationHook = offboarding.LogMigrationHook{}
+	if url := os.Getenv("PAN_MIGRATION_WEBHOOK_URL"); url != "" {
+		migrationHook = offboarding.NewWebhookMigrationHook(url)
+	}
+
+	// Deprecated routes and fields, with usage counted per API key
+	deprecations := deprecation.NewService(repository)
+	for _, notice := range deprecationNotices {
+		deprecations.Register(notice)
+	}
+
+	// Tenant-registered schemas keep resource metadata consistent
+	metadataSchemas := metadata.NewService(repository)
+
+	// Tenant-defined custom fields are collected on charges and invoiced
+	// subscriptions and printed on their receipts and invoices
+	customFields := customfields.NewService(repository, subscriptionService)
+	customFields.RegisterWebhookHandlers(webhookService)
+
+	// Branded invoices and receipts are rendered locally and kept in
+	// S3-compatible object storage once a bucket is configured
+	documentsConfig := documents.LoadConfig()
+	var documentObjects documents.ObjectStore
+	if documentsConfig.Enabled() {
+		documentObjects = documents.NewS3Store(documentsConfig)
+	}
+	documentService := documents.NewService(repository, documentObjects, documentsConfig)
+
+	// Customers' default and backup payment methods are kept locally and the
+	// default synced to the provider, so declined charges fall back in order
+	paymentMethodService := paymentmethods.NewService(repository, customerService, chargeService, invoiceService, subscriptionService)
+
+	// Orders paid across several methods are grouped into composite charges
+	// whose refunds are split across the original legs
+	compositeService := composite.NewService(repository, chargeService, refundGuard)
+	compositeService.RegisterWebhookHandlers(webhookService)
+
+	// Other services send commands such as refund requests over Kafka
+	commandService := commands.NewService(refundGuard, chargeStates, chargeService, subscriptionService, emitter)
+
+	// Tenants store their own provider credentials, encrypted at rest and
+	// verified with a test call before they are saved
+	providerCredentials := tenantcredentials.NewService(repository, credentialCipher, services.NewCredentialVerifier())
+
+	// Each tenant's requests are scoped to its own records, and its provider
+	// calls use its own credentials instead of the platform's
+	tenancyConfig := tenancy.LoadConfig()
+	tenantGateways := services.NewTenantGateways(providerCredentials, tenancyConfig.CacheTTL)
+	stripe.UseKeySource(tenantGateways)
+
+	// Gateway calls are retried and circuit broken per operation so a
+	// provider outage fails fast instead of cascading
+	var gatewayResilience *resilience.Policy
+	if resilienceConfig := resilience.LoadConfig(); resilienceConfig.Enabled {
+		gatewayResilience = services.NewResiliencePolicy(resilienceConfig)
+		tenantGateways.UseResilience(gatewayResilience)
+	}
+
+	// The simulator is a provider for integration testing without sandbox
+	// keys: test card numbers decide each charge's outcome, and its objects
+	// are stored per tenant. It is registered before the enabled providers
+	// are loaded so requests can select it.
+	var simulatorGateway *services.SimulatorGateway
+	if simulatorConfig := simulator.LoadConfig(); simulatorConfig.Enabled {
+		simulatorGateway = services.NewSimulatorGateway(simulator.New(repository, simulatorConfig))
+		services.GetFactory().RegisterProvider(simulator.Provider, func(map[string]interface{}) (services.PaymentGateway, error) {
+			return simulatorGateway, nil
+		})
+	}
+
+	// Charges created without capture are tracked until they are captured,
+	// in parts where the provider allows it, and voided before they expire
+	authorizationService := authorizations.NewService(repository, tenantGateways, emitter, authorizations.LoadConfig())
+	authorizationService.RegisterWebhookHandlers(webhookService)
+
+	// The webhook signing secret is rotated through the admin server, with
+	// both secrets accepted until deliveries with the new one are confirmed
+	webhookSecrets := webhooksecrets.NewService(repository, stripe.NewWebhookEndpointService(), credentialCipher, webhooksecrets.LoadConfig())
+	if err := webhookSecrets.Refresh(context.Background()); err != nil {
+		log.Printf("Warning: failed to load webhook secret rotations: %v", err)
+	}
+	webhookService.UseSecretSource(webhookSecrets)
+
+	// Quarantined API keys and tenants can read, but their mutations are held
+	// for release and replayed through the API once released
+	replayer := &requestReplayer{}
+	quarantineService := quarantine.NewService(repository, replayer)
+
+	// Each API key, or each tenant for other callers, is limited per
+	// endpoint. Buckets are shared between instances through Redis if chosen.
+	rateLimitConfig, err := ratelimit.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to configure rate limiting: %v", err)
+	}
+	var rateLimitStore ratelimit.Store = ratelimit.NewMemoryStore()
+	if rateLimitConfig.Backend == ratelimit.BackendRedis {
+		redisConfig, err := redis.LoadConfig()
+		if err != nil {
+			log.Fatalf("Failed to configure redis: %v", err)
+		}
+		if redisConfig == nil {
+			log.Fatalf("RATE_LIMIT_BACKEND=redis requires REDIS_URL")
+		}
+		rateLimitStore = ratelimit.NewRedisStore(redis.NewClient(redisConfig))
+	}
+
+	// Map service errors to localized display messages
+	translator := i18n.NewTranslator()
+	translator.Register(holds.ErrCustomerOnHold, i18n.KeyAccountOnHold)
+	translator.Register(auth.ErrUnauthenticated, i18n.KeyUnauthorized)
+	translator.Register(auth.ErrScopeNotAllowed, i18n.KeyNotPermitted)
+	translator.Register(auth.ErrKeyNotFound, i18n.KeyNotFound)
+	translator.Register(auth.ErrUnknownScope, i18n.KeyValidationFailed)
+	translator.Register(tenancy.ErrUnknownTenant, i18n.KeyNotPermitted)
+	translator.Register(tenancy.ErrTenantSuspended, i18n.KeyNotPermitted)
+	translator.Register(tenancy.ErrInvalidTenant, i18n.KeyValidationFailed)
+	translator.Register(tenancy.ErrNoCredentials, i18n.KeyNotPermitted)
+	translator.Register(ratelimit.ErrRateLimited, i18n.KeyTryAgainLater)
+	translator.Register(refundguard.ErrSelfApproval, i18n.KeyNotPermitted)
+	translator.Register(refundguard.ErrApproverRole, i18n.KeyNotPermitted)
+	translator.Register(refundguard.ErrApprovalNotFound, i18n.KeyNotFound)
+	translator.Register(refundguard.ErrRefundPolicy, i18n.KeyNotPermitted)
+	translator.Register(refundbatches.ErrBatchNotFound, i18n.KeyNotFound)
+	translator.Register(refundbatches.ErrNotCancelable, i18n.KeyNotPermitted)
+	translator.Register(refundbatches.ErrEmptyBatch, i18n.KeyValidationFailed)
+	translator.Register(refundbatches.ErrBatchTooLarge, i18n.KeyValidationFailed)
+	translator.Register(budgets.ErrBudgetExceeded, i18n.KeyNotPermitted)
+	translator.Register(blocklist.ErrBlocked, i18n.KeyNotPermitted)
+	translator.Register(disputes.ErrInvalidEvidence, i18n.KeyValidationFailed)
+	translator.Register(disputes.ErrDisputeClosed, i18n.KeyNotPermitted)
+	translator.Register(disputes.ErrEvidencePastDue, i18n.KeyNotPermitted)
+	translator.Register(fraud.ErrRejected, i18n.KeyNotPermitted)
+	translator.Register(fingerprints.ErrBlocked, i18n.KeyNotPermitted)
+	translator.Register(jobs.ErrJobNotFound, i18n.KeyNotFound)
+	translator.Register(jobs.ErrJobRunning, i18n.KeyTryAgainLater)
+	translator.Register(chargestate.ErrIllegalTransition, i18n.KeyNotPermitted)
+	translator.Register(chargestate.ErrUnknownState, i18n.KeyValidationFailed)
+	translator.Register(customers.ErrDuplicateCustomer, i18n.KeyDuplicateCustomer)
+	translator.Register(customers.ErrInvalidVerificationToken, i18n.KeyValidationFailed)
+	translator.Register(customers.ErrInvalidExternalReference, i18n.KeyValidationFailed)
+	translator.Register(customers.ErrInvalidCreatedRange, i18n.KeyValidationFailed)
+	translator.Register(customers.ErrSearchQueryRequired, i18n.KeyMissingParameter)
+	translator.Register(customers.ErrCustomerDeleted, i18n.KeyNotFound)
+	translator.Register(customers.ErrCustomerErased, i18n.KeyNotFound)
+	translator.Register(customers.ErrNotRestorable, i18n.KeyNotPermitted)
+	translator.Register(audit.ErrInvalidCreatedRange, i18n.KeyValidationFailed)
+	translator.Register(audit.ErrEntryNotFound, i18n.KeyNotFound)
+	translator.Register(chargesearch.ErrInvalidCreatedRange, i18n.KeyValidationFailed)
+	translator.Register(chargesearch.ErrInvalidAmountRange, i18n.KeyValidationFailed)
+	translator.Register(chargesearch.ErrInvalidCardLast4, i18n.KeyValidationFailed)
+	translator.Register(chargesearch.ErrUnsupportedFormat, i18n.KeyInvalidRequest)
+	translator.Register(customerstats.ErrInvalidWindow, i18n.KeyValidationFailed)
+	translator.Register(errInvalidPageLimit, i18n.KeyValidationFailed)
+	translator.Register(money.ErrInvalidDecimal, i18n.KeyInvalidAmount)
+	translator.Register(money.ErrAmountMismatch, i18n.KeyInvalidAmount)
+	translator.Register(metadata.ErrInvalidMetadata, i18n.KeyValidationFailed)
+	translator.Register(metadata.ErrInvalidSchema, i18n.KeyValidationFailed)
+	translator.Register(customfields.ErrInvalidCustomFields, i18n.KeyValidationFailed)
+	translator.Register(customfields.ErrInvalidDefinition, i18n.KeyValidationFailed)
+	translator.Register(composite.ErrInvalidCompositeCharge, i18n.KeyValidationFailed)
+	translator.Register(composite.ErrInvalidRefund, i18n.KeyValidationFailed)
+	translator.Register(composite.ErrExceedsRefundable, i18n.KeyInvalidAmount)
+	translator.Register(history.ErrNoVersion, i18n.KeyNotFound)
+	translator.Register(stripe.ErrInvalidPaymentTerms, i18n.KeyValidationFailed)
+	translator.Register(stripe.ErrNoScheduledChange, i18n.KeyNotFound)
+	translator.Register(stripe.ErrScheduleConflict, i18n.KeyNotPermitted)
+	translator.Register(stripe.ErrNotReactivatable, i18n.KeyNotPermitted)
+	translator.Register(subscriptions.ErrEmptyUpdate, i18n.KeyValidationFailed)
+	translator.Register(subscriptions.ErrInvalidStatus, i18n.KeyValidationFailed)
+	translator.Register(subscriptions.ErrEmptyChange, i18n.KeyValidationFailed)
+	translator.Register(subscriptions.ErrInvalidQuantity, i18n.KeyValidationFailed)
+	translator.Register(subscriptions.ErrProrationDate, i18n.KeyValidationFailed)
+	translator.Register(subscriptions.ErrCurrencyMismatch, i18n.KeyNotPermitted)
+	translator.Register(dunning.ErrCaseNotFound, i18n.KeyNotFound)
+	translator.Register(dunning.ErrCaseResolved, i18n.KeyNotPermitted)
+	translator.Register(authorizations.ErrAuthorizationNotFound, i18n.KeyNotFound)
+	translator.Register(authorizations.ErrAuthorizationClosed, i18n.KeyNotPermitted)
+	translator.Register(authorizations.ErrAuthorizationExpired, i18n.KeyNotPermitted)
+	translator.Register(authorizations.ErrExceedsAuthorization, i18n.KeyValidationFailed)
+	translator.Register(authorizations.ErrSingleCapture, i18n.KeyValidationFailed)
+	translator.Register(usage.ErrInvalidUsage, i18n.KeyValidationFailed)
+	translator.Register(stripe.ErrNotMetered, i18n.KeyNotPermitted)
+	translator.Register(stripe.ErrDaysUntilDueRequired, i18n.KeyValidationFailed)
+	translator.Register(stripe.ErrInvalidApplicationFee, i18n.KeyValidationFailed)
+	translator.Register(stripe.ErrSetupIntentNotSucceeded, i18n.KeyNotPermitted)
+	translator.Register(stripe.ErrBankAccountRequired, i18n.KeyValidationFailed)
+	translator.Register(stripe.ErrMandateRequired, i18n.KeyValidationFailed)
+	translator.Register(stripe.ErrInvalidMicrodeposits, i18n.KeyValidationFailed)
+	translator.Register(stripe.ErrUnsupportedWallet, i18n.KeyValidationFailed)
+	translator.Register(stripe.ErrInvalidWalletToken, i18n.KeyValidationFailed)
+	translator.Register(stripe.ErrDateOfBirthRequired, i18n.KeyValidationFailed)
+	translator.Register(stripe.ErrShippingRequired, i18n.KeyValidationFailed)
+	translator.Register(stripe.ErrNotCapturable, i18n.KeyNotPermitted)
+	translator.Register(stripe.ErrCaptureWindowExpired, i18n.KeyNotPermitted)
+	translator.Register(errUnsupportedBNPL, i18n.KeyNotPermitted)
+	translator.Register(money.ErrInvalidCurrency, i18n.KeyCurrencyNotSupported)
+	translator.Register(money.ErrBelowMinimum, i18n.KeyInvalidAmount)
+	translator.Register(fx.ErrUnsupportedCurrency, i18n.KeyCurrencyNotSupported)
+	translator.Register(fx.ErrRatesUnavailable, i18n.KeyTryAgainLater)
+	translator.Register(invoicing.ErrPDFUnavailable, i18n.KeyNotPermitted)
+	translator.Register(paymentlinks.ErrExpiryInPast, i18n.KeyValidationFailed)
+	translator.Register(tenantcredentials.ErrInvalidCredentials, i18n.KeyValidationFailed)
+	translator.Register(tenantcredentials.ErrVerificationFailed, i18n.KeyValidationFailed)
+	translator.Register(merchantwebhooks.ErrInvalidEndpoint, i18n.KeyValidationFailed)
+	translator.Register(eventarchive.ErrInvalidReplay, i18n.KeyValidationFailed)
+	translator.Register(receipts.ErrInvalidDescriptor, i18n.KeyValidationFailed)
+	translator.Register(receipts.ErrInvalidSettings, i18n.KeyValidationFailed)
+	translator.Register(documents.ErrInvalidTemplate, i18n.KeyValidationFailed)
+	translator.Register(documents.ErrUnsupportedLanguage, i18n.KeyValidationFailed)
+	translator.Register(documents.ErrStorageUnavailable, i18n.KeyGenericError)
+	translator.Register(paymentmethods.ErrInvalidPreference, i18n.KeyValidationFailed)
+	translator.Register(paymentmethods.ErrNotAttached, i18n.KeyValidationFailed)
+	translator.Register(customerbalance.ErrInvalidCredit, i18n.KeyValidationFailed)
+	translator.Register(customerbalance.ErrRefundExceedsCharge, i18n.KeyValidationFailed)
+	translator.Register(customerbalance.ErrInsufficientBalance, i18n.KeyValidationFailed)
+	translator.Register(analytics.ErrInvalidRange, i18n.KeyValidationFailed)
+	translator.Register(analytics.ErrRangeTooLarge, i18n.KeyValidationFailed)
+	translator.Register(analytics.ErrInvalidGranularity, i18n.KeyValidationFailed)
+	translator.Register(services.ErrProviderNotEnabled, i18n.KeyValidationFailed)
+	translator.Register(errProviderNotServed, i18n.KeyNotPermitted)
+	translator.Register(errConflictingProvider, i18n.KeyValidationFailed)
 
 	// Create Fiber app
 	fiberApp := fiber.New(fiber.Config{
@@ -43,27 +725,172 @@ func NewApp() *App {
 		ReadTimeout:  30 * time.Second,
 		WriteTimeout: 30 * time.Second,
 		IdleTimeout:  120 * time.Second,
+		BodyLimit:    8 * 1024 * 1024, // Dispute evidence files may be up to 5MB
 	})
 
 	// Add middleware
 	fiberApp.Use(recover.New())
-	fiberApp.Use(logger.New())
+	fiberApp.Use(assignRequestID)
+	fiberApp.Use(logger.New(logger.Config{
+		Format: "${time} | ${status} | ${latency} | ${ip} | ${method} | ${path} | ${locals:" + requestIDLocal + "} | ${error}\n",
+	}))
 	fiberApp.Use(cors.New(cors.Config{
-		AllowOrigins: "*",
-		AllowMethods: "GET,POST,PUT,DELETE,OPTIONS",
-		AllowHeaders: "Origin,Content-Type,Accept,Authorization",
+		AllowOrigins:  "*",
+		AllowMethods:  "GET,POST,PUT,DELETE,OPTIONS",
+		AllowHeaders:  "Origin,Content-Type,Accept,Accept-Language,Authorization,X-Tenant-ID,X-Operator-ID," + requestid.Header,
+		ExposeHeaders: requestid.Header,
 	}))
 
+	// In-flight requests are tracked so shutdown can drain them
+	drainTracker := drain.NewTracker()
+
 	// Register routes
 	app := &App{
-		fiberApp:        fiberApp,
-		customerService: customerService,
-		chargeService:   chargeService,
-		refundService:   refundService,
+		fiberApp:            fiberApp,
+		connectionManager:   connectionManager,
+		repository:          repository,
+		customerService:     customerService,
+		chargeService:       chargeService,
+		customerCache:       customerCache,
+		chargeCache:         chargeCache,
+		cacheMetrics:        cacheMetrics,
+		wallets:             stripe.NewWalletService(),
+		refundService:       refundService,
+		subscriptionService: subscriptionService,
+		subscriptions:       subscriptionLifecycle,
+		usage:               usageService,
+		connectService:      stripe.NewConnectService(),
+		webhookService:      webhookService,
+		paddleWebhooks:      paddleWebhooks,
+		paypalWebhooks:      paypalWebhooks,
+		holdService:         holdService,
+		refundGuard:         refundGuard,
+		translator:          translator,
+		gatewayRecorder:     gatewayRecorder,
+		eventSource:         eventSource,
+		metadataSchemas:     metadataSchemas,
+		customFields:        customFields,
+		composite:           compositeService,
+		projections:         projectionService,
+		historyService:      historyService,
+		deprecations:        deprecations,
+		autoRefunds:         autoRefunds,
+		authorizations:      authorizationService,
+		invoicing:           invoicingService,
+		dunning:             dunningService,
+		vault:               vaultService,
+		router:              router,
+		smartRouting:        smartRouting,
+		disputes:            disputeService,
+		disputeEvidence:     disputeEvidence,
+		ephemeralKeys:       ephemeralkeys.NewService(repository, ephemeralkeys.LoadConfig()),
+		auth:                auth.NewService(repository, auth.LoadConfig()),
+		budgets:             budgetService,
+		drain:               drainTracker,
+		customerIdentities:  customerIdentities,
+		customerUpserts:     customers.NewUpserter(repository, customerService, customerIdentities),
+		customerSearch:      customers.NewSearcher(repository),
+		customerLifecycle:   customers.NewLifecycle(repository, customerService, emitter, customersConfig),
+		auditLog:            audit.NewService(repository),
+		chargeSearch:        chargesearch.NewService(repository),
+		customerStats:       customerStats,
+		dryRunConfig:        dryrun.LoadConfig(),
+		drainConfig:         drain.LoadConfig(),
+		radar:               radarService,
+		blocklist:           blocklistService,
+		fingerprints:        fingerprints.NewService(repository, emitter, fingerprints.LoadConfig()),
+		chargeStates:        chargeStates,
+		quarantine:          quarantineService,
+		replayer:            replayer,
+		backpressure:        backpressure.NewMonitor(backpressure.LoadConfig()),
+		rateLimits:          ratelimit.NewService(rateLimitStore, rateLimitConfig),
+		runMode:             runMode,
+		commands:            commandService,
+		kafkaConfig:         kafkaConfig,
+		eventPublisher:      eventPublisher,
+		providerCredentials: providerCredentials,
+		tenancy:             tenancy.NewService(repository, tenancyConfig),
+		tenantGateways:      tenantGateways,
+		providers:           services.LoadEnabledProviders(),
+		gatewayResilience:   gatewayResilience,
+		webhookSecrets:      webhookSecrets,
+		deadLetters:         deadletter.NewService(repository, deadletter.LoadConfig()),
+		merchantWebhooks:    merchantWebhooks,
+		relayOutbox:         relayOutbox,
+		eventArchive:        eventArchive,
+		receipts:            receipts.NewService(repository),
+		documents:           documentService,
+		paymentMethods:      paymentMethodService,
+		customerBalances:    customerBalances,
+		offboarding:         offboarding.NewService(repository, migrationHook, offboarding.LoadConfig()),
+		refundBatches:       refundbatches.NewService(repository, refundGuard, chargeStates, refundbatches.LoadConfig()),
+		graphqlConfig:       graphql.LoadConfig(),
+		analyticsWriter:     analyticsWriter,
+		analyticsConfig:     analyticsConfig,
+		analyticsIngester:   analyticsIngester,
+		analytics:           analyticsService,
+		paymentLinks:        paymentLinks,
+		ledger:              ledgerService,
+		reconciliation:      reconciliationService,
+		jobs:                jobs.NewScheduler(repository, repository, jobs.LoadConfig()),
+		config:              loader,
+		fx:                  fxService,
+		grpcConfig:          grpcserver.LoadConfig(),
+		simulator:           simulatorGateway,
 	}
+	replayer.app = fiberApp
+
+	// Charges are screened for fraud before they are created; accepted and
+	// approved charges go through the same routing and budget bookkeeping
+	app.fraud = fraud.NewService(repository, &fraudCharger{app: app}, emitter, fraud.LoadConfig())
+	app.fraud.Use(fingerprints.NewFraudRule(app.fingerprints))
+
+	// Periodic jobs run on the worker role, one instance at a time. They are
+	// registered everywhere so any instance can list and start them.
+	for _, job := range []*jobs.Job{
+		app.dunning.Job(),
+		app.authorizations.Job(),
+		app.reconciliation.Job(),
+		app.eventArchive.Job(),
+	} {
+		if err := app.jobs.Register(job); err != nil {
+			log.Fatalf("Failed to schedule jobs: %v", err)
+		}
+	}
+	fiberApp.Use(app.trackInFlight)
 
+	app.registerDeadLetterReplayers()
+	app.adminApp = app.newAdminApp()
 	app.registerRoutes()
 
+	// Internal services call the gRPC API on its own TLS port. Calls
+	// authenticate, resolve their tenant and are rate limited, quarantined
+	// and audited like REST requests. Charges pass the same holds, budgets,
+	// card blocks, fraud screening and customer credit as REST charges, then
+	// go straight to the tenant's gateway, or through the provider chain of a
+	// failover rule, ordered by smart routing when it is enabled. Calls may
+	// select any enabled provider instead.
+	if app.grpcConfig.Enabled && runMode.ServesAPI() {
+		creds, err := app.grpcConfig.Credentials()
+		if err != nil {
+			log.Fatalf("Failed to configure gRPC API: %v", err)
+		}
+
+		grpcService := grpcserver.NewService(tenantGateways, app.auth, app.tenancy)
+		grpcService.UseProviders(app.providers)
+		if policy := services.GetFactory().RoutingPolicy(); policy != nil || len(router.FailoverRules()) > 0 {
+			grpcService.UseFailover(router, policy)
+		}
+		grpcService.UseAuthorizations(authorizationService)
+		grpcService.UseChargePolicy(&gatewayChargePolicy{app: app})
+		grpcService.UseRefundPolicy(&refundPolicy{app: app})
+		grpcService.UseDisputePolicy(&disputePolicy{app: app})
+		grpcService.UseRateLimits(app.rateLimits)
+		grpcService.UseQuarantine(app.quarantine)
+		grpcService.UseAuditLog(app.auditLog)
+		app.grpcServer = grpcserver.NewServer(grpcService, creds)
+	}
+
 	return app
 }
 
@@ -72,56 +899,379 @@ func (a *App) registerRoutes() {
 	// Health check
 	a.fiberApp.Get("/health", func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{
-			"status":  "healthy",
-			"service": "payments",
-			"time":    time.Now().UTC(),
+			"status":   "healthy",
+			"service":  "payments",
+			"role":     a.runMode,
+			"time":     time.Now().UTC(),
+			"gateways": a.gatewayHealth(),
 		})
 	})
 
-	// API routes
-	api := a.fiberApp.Group("/api/v1")
+	// Readiness check, failing once shutdown begins
+	a.fiberApp.Get("/ready", a.ready)
+
+	// Worker instances serve only health checks
+	if !a.runMode.ServesAPI() {
+		return
+	}
+
+	// The API's OpenAPI document and Swagger UI, served without credentials
+	a.fiberApp.Get(apiPrefix+"/openapi.json", a.getOpenAPISpec)
+	a.fiberApp.Get(apiPrefix+"/docs", a.getAPIDocs)
+
+	// API routes, authenticated, bound to their tenant and rate limited before
+	// quarantine so only known callers are held. Mutations that get through
+	// are audited.
+	api := a.fiberApp.Group(apiPrefix, a.authenticate, a.resolveTenant, a.selectProvider, a.rateLimit, a.quarantineGate, a.auditCalls)
+
+	// API key routes, for admin keys
+	apiKeys := api.Group("/api-keys")
+	apiKeys.Get("/", a.listAPIKeys)
+	apiKeys.Post("/", a.createAPIKey)
+	apiKeys.Post("/:id/rotate", a.rotateAPIKey)
+	apiKeys.Delete("/:id", a.revokeAPIKey)
 
 	// Customer routes
 	customers := api.Group("/customers")
 	customers.Post("/", a.createCustomer)
+	customers.Get("/", a.listCustomers)
+	customers.Put("/upsert", a.upsertCustomer)
+	customers.Get("/search", a.searchCustomers)
 	customers.Get("/:id", a.getCustomer)
 	customers.Put("/:id", a.updateCustomer)
 	customers.Delete("/:id", a.deleteCustomer)
+	customers.Post("/:id/restore", a.restoreCustomer)
+	customers.Post("/:id/erase", a.eraseCustomer)
+	customers.Get("/:id/stats", a.getCustomerStats)
+	customers.Get("/:id/balance", a.getCustomerBalance)
+	customers.Post("/:id/balance/credits", a.creditCustomerBalance)
+	customers.Post("/:id/balance/reconcile", a.reconcileCustomerBalance)
+	customers.Get("/:id/email-verification", a.getEmailVerification)
+	customers.Post("/:id/email-verification", a.requestEmailVerification)
+	customers.Post("/:id/email-verification/confirm", a.verifyCustomerEmail)
 
 	// Payment method routes
 	paymentMethods := api.Group("/customers/:customerId/payment-methods")
 	paymentMethods.Post("/", a.addPaymentMethod)
 	paymentMethods.Get("/", a.listPaymentMethods)
+	paymentMethods.Get("/default", a.getPaymentMethodPreference)
+	paymentMethods.Put("/default", a.setDefaultPaymentMethod)
+	paymentMethods.Put("/backups", a.setBackupPaymentMethods)
 	paymentMethods.Get("/:id", a.getPaymentMethod)
 	paymentMethods.Delete("/:id", a.detachPaymentMethod)
 
+	// Setup intent routes: cards are saved for off-session use from details
+	// the frontend collects with Stripe.js, without raw tokens
+	setupIntents := api.Group("/customers/:customerId/setup-intents")
+	setupIntents.Post("/", a.createSetupIntent)
+	setupIntents.Get("/:id", a.getSetupIntent)
+	setupIntents.Post("/:id/confirm", a.confirmSetupIntent)
+	setupIntents.Post("/:id/cancel", a.cancelSetupIntent)
+	setupIntents.Post("/:id/verify-microdeposits", a.verifyMicrodeposits)
+
 	// Charge routes
 	charges := api.Group("/charges")
-	charges.Post("/", a.createCharge)
-	charges.Get("/:id", a.getCharge)
-	charges.Get("/", a.listCharges)
+	charges.Post("/", a.deprecated(deprecatedCreateCharge), a.createCharge)
+	charges.Post("/wallet", a.createWalletCharge)
+	charges.Get("/:id", a.deprecated(deprecatedGetCharge), a.getCharge)
+	charges.Get("/:id/history", a.entityHistory(history.EntityCharge))
+	charges.Get("/:id/transitions", a.getChargeTransitions)
+	charges.Get("/:id/receipt", a.getChargeReceipt)
+	charges.Get("/:id/receipt/document", a.getChargeReceiptDocument)
+	charges.Get("/", a.deprecated(deprecatedListCharges), a.listCharges)
+
+	// Buy-now-pay-later payments authorized by redirect
+	paymentIntents := api.Group("/payment-intents")
+	paymentIntents.Post("/", a.createPaymentIntent)
+	paymentIntents.Get("/:id", a.getPaymentIntent)
+	paymentIntents.Post("/:id/capture", a.capturePaymentIntent)
+	paymentIntents.Post("/:id/cancel", a.cancelPaymentIntent)
+
+	// Apple Pay and Google Pay domains
+	walletDomains := api.Group("/wallets/domains")
+	walletDomains.Post("/", a.registerWalletDomain)
+	walletDomains.Get("/", a.listWalletDomains)
+	walletDomains.Post("/:id/validate", a.validateWalletDomain)
 
 	// Refund routes
 	refunds := api.Group("/refunds")
 	refunds.Post("/", a.createRefund)
+	refunds.Post("/batch", a.createRefundBatch)
+	refunds.Get("/batch/:id", a.getRefundBatch)
+	refunds.Post("/batch/:id/cancel", a.cancelRefundBatch)
 	refunds.Get("/:id", a.getRefund)
 	refunds.Get("/", a.listRefunds)
+
+	// Dashboard routes served from read models
+	api.Get("/dashboard/charges", a.listChargeRows)
+
+	// Analytics routes
+	api.Get("/analytics/credentials", a.getCredentialStats)
+	api.Get("/analytics/routing", a.getRoutingReport)
+	api.Get("/analytics/charges", a.getChargeAnalytics)
+	api.Get("/analytics/revenue", a.getRevenueAnalytics)
+	api.Get("/analytics/mrr", a.getMRRAnalytics)
+	api.Get("/analytics/churn", a.getChurnAnalytics)
+	api.Get("/analytics/decline-reasons", a.getDeclineReasons)
+
+	// Simulator test cards and 3D Secure authentication
+	api.Get("/simulator/cards", a.listSimulatorCards)
+	api.Post("/simulator/charges/:id/authenticate", a.authenticateSimulatorCharge)
+
+	// Monthly processing budget for the requesting tenant
+	api.Get("/budget", a.getBudgetStatus)
+
+	// Subscription routes
+	subscriptions := api.Group("/subscriptions")
+	subscriptions.Post("/", a.createSubscription)
+	subscriptions.Get("/", a.listSubscriptions)
+	subscriptions.Post("/invoiced", a.createInvoicedSubscription)
+	subscriptions.Get("/:id", a.getSubscription)
+	subscriptions.Put("/:id", a.updateSubscription)
+	subscriptions.Post("/:id/preview", a.previewSubscriptionChange)
+	subscriptions.Post("/:id/cancel", a.cancelSubscription)
+	subscriptions.Post("/:id/reactivate", a.reactivateSubscription)
+	subscriptions.Post("/:id/usage", a.reportUsage)
+	subscriptions.Get("/:id/usage", a.getUsage)
+	subscriptions.Get("/:id/history", a.entityHistory(history.EntitySubscription))
+	subscriptions.Post("/:id/scheduled-change", a.schedulePlanChange)
+	subscriptions.Delete("/:id/scheduled-change", a.cancelPlanChange)
+	subscriptions.Put("/:id/payment-terms", a.updatePaymentTerms)
+
+	// Plan routes. Plans are recurring provider prices; they are archived
+	// rather than deleted.
+	plans := api.Group("/plans")
+	plans.Post("/", a.createPlan)
+	plans.Get("/", a.listPlans)
+	plans.Get("/:id", a.getPlan)
+	plans.Put("/:id", a.updatePlan)
+	plans.Delete("/:id", a.archivePlan)
+
+	// Connected account routes. Destination charges are created with
+	// POST /charges, naming the account as the destination.
+	accounts := api.Group("/accounts")
+	accounts.Post("", a.createConnectedAccount)
+	accounts.Get("", a.listConnectedAccounts)
+	accounts.Get("/:id", a.getConnectedAccount)
+	accounts.Post("/:id/onboarding-links", a.createOnboardingLink)
+	accounts.Post("/:id/transfers", a.createTransfer)
+	accounts.Get("/:id/transfers", a.listTransfers)
+
+	// Currency routes
+	api.Get("/currencies/convert", a.convertCurrency)
+
+	// Ledger routes
+	ledgerRoutes := api.Group("/ledger")
+	ledgerRoutes.Get("/accounts", a.listLedgerAccounts)
+	ledgerRoutes.Get("/accounts/:id/entries", a.listLedgerAccountEntries)
+	ledgerRoutes.Get("/trial-balance", a.getTrialBalance)
+
+	// Reconciliation routes
+	reconciliationRoutes := api.Group("/reconciliation")
+	reconciliationRoutes.Get("/runs", a.listReconciliationRuns)
+	reconciliationRoutes.Get("/runs/:id", a.getReconciliationReport)
+
+	// Outgoing webhook routes
+	webhookEndpoints := api.Group("/webhook-endpoints")
+	webhookEndpoints.Post("", a.createWebhookEndpoint)
+	webhookEndpoints.Get("", a.listWebhookEndpoints)
+	webhookEndpoints.Get("/:id", a.getWebhookEndpoint)
+	webhookEndpoints.Put("/:id", a.updateWebhookEndpoint)
+	webhookEndpoints.Delete("/:id", a.deleteWebhookEndpoint)
+	webhookEndpoints.Get("/:id/deliveries", a.listWebhookDeliveries)
+	api.Get("/webhook-deliveries/:id", a.getWebhookDelivery)
+	api.Post("/webhook-deliveries/:id/redeliver", a.redeliverWebhookDelivery)
+
+	// Payment link routes
+	paymentLinks := api.Group("/payment-links")
+	paymentLinks.Post("", a.createPaymentLink)
+	paymentLinks.Get("", a.listPaymentLinks)
+	paymentLinks.Get("/:id", a.getPaymentLink)
+	paymentLinks.Get("/:id/conversions", a.listPaymentLinkConversions)
+	paymentLinks.Post("/:id/deactivate", a.deactivatePaymentLink)
+
+	// Invoice routes
+	invoices := api.Group("/invoices")
+	invoices.Get("/overdue", a.listOverdueInvoices)
+	invoices.Get("", a.listInvoices)
+	invoices.Post("", a.createInvoice)
+	invoices.Get("/:id", a.getInvoice)
+	invoices.Post("/:id/finalize", a.finalizeInvoice)
+	invoices.Post("/:id/pay", a.payInvoice)
+	invoices.Post("/:id/void", a.voidInvoice)
+	invoices.Get("/:id/pdf", a.getInvoicePDF)
+	invoices.Get("/:id/document", a.getInvoiceDocument)
+	invoices.Post("/:id/mark-paid", a.markInvoicePaid)
+
+	// Dunning routes
+	dunningCases := api.Group("/dunning")
+	dunningCases.Get("", a.listDunningCases)
+	dunningCases.Get("/:id", a.getDunningCase)
+	dunningCases.Post("/:id/retry", a.retryDunningCase)
+
+	// Authorization routes
+	authorizationRoutes := api.Group("/authorizations")
+	authorizationRoutes.Get("", a.listAuthorizations)
+	authorizationRoutes.Get("/:id", a.getAuthorization)
+	authorizationRoutes.Post("/:id/capture", a.captureAuthorization)
+	authorizationRoutes.Post("/:id/void", a.voidAuthorization)
+
+	// Dispute routes
+	api.Get("/disputes", a.listDisputes)
+	api.Get("/disputes/:id", a.getDispute)
+	api.Get("/disputes/:id/history", a.entityHistory(history.EntityDispute))
+	api.Get("/disputes/:id/evidence", a.getDisputeEvidence)
+	api.Put("/disputes/:id/evidence", a.updateDisputeEvidence)
+	api.Post("/disputes/:id/evidence/files", a.uploadDisputeEvidenceFile)
+	api.Post("/disputes/:id/evidence/submit", a.submitDisputeEvidence)
+
+	// Ephemeral keys for frontend clients
+	api.Post("/ephemeral-keys", a.createEphemeralKey)
+	api.Delete("/ephemeral-keys/:id", a.revokeEphemeralKey)
+
+	// Client routes authenticated by an ephemeral key, scoped to its customer
+	client := api.Group("/client")
+	client.Get("/payment-methods", a.ephemeralKeyAuth(ephemeralkeys.ScopePaymentMethodsRead), a.listClientPaymentMethods)
+	client.Post("/payment-methods", a.ephemeralKeyAuth(ephemeralkeys.ScopePaymentMethodsWrite), a.addClientPaymentMethod)
+	client.Delete("/payment-methods/:id", a.ephemeralKeyAuth(ephemeralkeys.ScopePaymentMethodsWrite), a.detachClientPaymentMethod)
+	client.Post("/setup-intents", a.ephemeralKeyAuth(ephemeralkeys.ScopePaymentMethodsWrite), a.createClientSetupIntent)
+	client.Post("/setup-intents/:id/confirm", a.ephemeralKeyAuth(ephemeralkeys.ScopePaymentMethodsWrite), a.confirmClientSetupIntent)
+	client.Post("/setup-intents/:id/verify-microdeposits", a.ephemeralKeyAuth(ephemeralkeys.ScopePaymentMethodsWrite), a.verifyClientMicrodeposits)
+
+	// Provider-agnostic payment method tokens
+	api.Get("/vault/tokens", a.listVaultTokens)
+	api.Get("/vault/tokens/:id", a.getVaultToken)
+
+	// Metadata schema routes
+	metadataSchemas := api.Group("/metadata-schemas")
+	metadataSchemas.Get("/", a.listMetadataSchemas)
+	metadataSchemas.Get("/:resource", a.getMetadataSchema)
+	metadataSchemas.Put("/:resource", a.registerMetadataSchema)
+	metadataSchemas.Delete("/:resource", a.deleteMetadataSchema)
+
+	// Custom field routes
+	api.Get("/custom-fields", a.getCustomFields)
+	api.Put("/custom-fields", a.defineCustomFields)
+	api.Delete("/custom-fields", a.deleteCustomFields)
+
+	// Receipt settings routes
+	api.Get("/receipt-settings", a.getReceiptSettings)
+	api.Put("/receipt-settings", a.updateReceiptSettings)
+
+	// Branded document routes
+	api.Get("/documents/:id", a.getDocument)
+	api.Get("/document-templates/:kind", a.getDocumentTemplate)
+	api.Put("/document-templates/:kind", a.updateDocumentTemplate)
+	api.Delete("/document-templates/:kind", a.deleteDocumentTemplate)
+
+	// Tenant provider credential routes
+	providerCredentials := api.Group("/provider-credentials")
+	providerCredentials.Get("/", a.listProviderCredentials)
+	providerCredentials.Get("/:provider", a.getProviderCredential)
+	providerCredentials.Put("/:provider", a.saveProviderCredential)
+	providerCredentials.Post("/:provider/rotate", a.rotateProviderCredential)
+	providerCredentials.Delete("/:provider", a.deleteProviderCredential)
+	providerCredentials.Get("/:provider/audit", a.listProviderCredentialAudit)
+
+	// Composite charge routes
+	compositeCharges := api.Group("/composite-charges")
+	compositeCharges.Post("/", a.createCompositeCharge)
+	compositeCharges.Get("/:id", a.getCompositeCharge)
+	compositeCharges.Post("/:id/refunds", a.refundCompositeCharge)
+	compositeCharges.Get("/:id/refunds", a.listCompositeRefunds)
+	api.Get("/composite-refunds/:id", a.getCompositeRefund)
+
+	// Refund approval routes
+	refundApprovals := api.Group("/refund-approvals")
+	refundApprovals.Get("/", a.listRefundApprovals)
+	refundApprovals.Get("/:id", a.getRefundApproval)
+	refundApprovals.Post("/:id/approve", a.approveRefund)
+	refundApprovals.Post("/:id/reject", a.rejectRefund)
+
+	// Automatic refund routes
+	api.Get("/auto-refunds", a.listAutoRefunds)
+	api.Get("/auto-refund-exclusions", a.listAutoRefundExclusions)
+	api.Put("/auto-refund-exclusions/:customerId", a.excludeFromAutoRefunds)
+	api.Delete("/auto-refund-exclusions/:customerId", a.includeInAutoRefunds)
+
+	// Blocklist routes, mirrored to the provider's fraud tooling
+	api.Get("/blocklist", a.listBlocklistEntries)
+	api.Post("/blocklist", a.addBlocklistEntry)
+	api.Delete("/blocklist/:id", a.removeBlocklistEntry)
+	api.Get("/card-fingerprints/:fingerprint", a.getCardFingerprint)
+
+	// Fraud screening routes
+	fraudReviews := api.Group("/fraud-reviews")
+	fraudReviews.Get("/", a.listFraudReviews)
+	fraudReviews.Get("/:id", a.getFraudReview)
+	fraudReviews.Post("/:id/approve", a.approveFraudReview)
+	fraudReviews.Post("/:id/reject", a.rejectFraudReview)
+	api.Get("/fraud-lists", a.listFraudListEntries)
+	api.Post("/fraud-lists", a.addFraudListEntry)
+	api.Delete("/fraud-lists/:id", a.removeFraudListEntry)
+
+	// Radar value list passthrough
+	valueLists := api.Group("/radar/value-lists")
+	valueLists.Get("/", a.listValueLists)
+	valueLists.Post("/", a.createValueList)
+	valueLists.Get("/:id", a.getValueList)
+	valueLists.Delete("/:id", a.deleteValueList)
+	valueLists.Post("/:id/items", a.addValueListItem)
+	api.Delete("/radar/value-list-items/:id", a.removeValueListItem)
+
+	// Hold routes
+	api.Get("/customers/:customerId/holds", a.listCustomerHolds)
+	api.Post("/holds/:id/release", a.releaseHold)
+
+	// GraphQL queries across customers, charges, subscriptions and disputes
+	a.graphqlSchema = a.buildGraphQLSchema()
+	api.Post("/graphql", a.handleGraphQL)
+
+	// Webhook routes
+	webhooks := a.fiberApp.Group("/webhooks", a.webhookBackpressure)
+	webhooks.Post("/stripe", a.handleStripeWebhook)
+	webhooks.Post("/paddle", a.handlePaddleWebhook)
+	webhooks.Post("/paypal", a.handlePayPalWebhook)
+
+	// Described once every route is registered
+	a.apiSpec = a.buildAPISpec()
 }
 
 // createCustomer handles customer creation
 func (a *App) createCustomer(c *fiber.Ctx) error {
 	var request stripe.CustomerRequest
 	if err := c.BodyParser(&request); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	if err := a.checkMetadata(c, metadata.ResourceCustomer, request.Metadata); err != nil {
+		return a.errorResponse(c, metadataErrorStatus(err), err)
+	}
+
+	// Reject creating the same person twice in a tenant unless the caller insists
+	tenantID := requestTenant(c)
+	if !c.QueryBool("allow_duplicate") {
+		err := a.customerIdentities.CheckDuplicate(c.Context(), tenantID, request.Email, request.Name)
+		var duplicate *customers.DuplicateError
+		if errors.As(err, &duplicate) {
+			return a.duplicateCustomerResponse(c, duplicate)
+		}
+		if err != nil {
+			return a.errorResponse(c, fiber.StatusInternalServerError, err)
+		}
+	}
+
+	if err := a.blocklist.Check(c.Context(), blocklist.TypeEmail, request.Email); err != nil {
+		return a.errorResponse(c, blocklistErrorStatus(err), err)
 	}
 
 	customer, err := a.customerService.CreateCustomer(c.Context(), &request)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	if _, err := a.customerIdentities.Register(c.Context(), tenantID, customer.ID, customer.Email, customer.Name); err != nil {
+		requestid.Logf(c.Context(), "Failed to record identity for customer %s: %v", customer.ID, err)
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(customer)
@@ -131,16 +1281,16 @@ func (a *App) createCustomer(c *fiber.Ctx) error {
 func (a *App) getCustomer(c *fiber.Ctx) error {
 	customerID := c.Params("id")
 	if customerID == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Customer ID is required",
-		})
+		return a.errorMessage(c, fiber.StatusBadRequest, "Customer ID is required", i18n.KeyMissingParameter)
 	}
 
-	customer, err := a.customerService.GetCustomer(c.Context(), customerID)
+	if err := a.customerLifecycle.Check(c.Context(), customerID); err != nil {
+		return a.errorResponse(c, customerLifecycleErrorStatus(err), err)
+	}
+
+	customer, err := a.customerCache.Get(c.Context(), customerID)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return a.errorResponse(c, fiber.StatusNotFound, err)
 	}
 
 	return c.JSON(customer)
@@ -150,128 +1300,184 @@ func (a *App) getCustomer(c *fiber.Ctx) error {
 func (a *App) updateCustomer(c *fiber.Ctx) error {
 	customerID := c.Params("id")
 	if customerID == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Customer ID is required",
-		})
+		return a.errorMessage(c, fiber.StatusBadRequest, "Customer ID is required", i18n.KeyMissingParameter)
 	}
 
 	var request stripe.CustomerRequest
 	if err := c.BodyParser(&request); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	if err := a.checkMetadataUpdate(c, metadata.ResourceCustomer, request.Metadata); err != nil {
+		return a.errorResponse(c, metadataErrorStatus(err), err)
+	}
+
+	if err := a.customerLifecycle.Check(c.Context(), customerID); err != nil {
+		return a.errorResponse(c, customerLifecycleErrorStatus(err), err)
 	}
 
 	customer, err := a.customerService.UpdateCustomer(c.Context(), customerID, &request)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+	a.customerCache.Invalidate(c.Context(), customerID)
+
+	// Customers created before identities were recorded have none to update
+	_, err = a.customerIdentities.Update(c.Context(), customerID, customer.Email, customer.Name)
+	if err != nil && !errors.Is(err, customers.ErrIdentityNotFound) {
+		requestid.Logf(c.Context(), "Failed to update identity for customer %s: %v", customerID, err)
 	}
 
 	return c.JSON(customer)
 }
 
-// deleteCustomer handles customer deletion
+// deleteCustomer handles customer deletion. The customer is kept until its
+// retention period ends and can be restored until then.
 func (a *App) deleteCustomer(c *fiber.Ctx) error {
 	customerID := c.Params("id")
 	if customerID == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Customer ID is required",
-		})
+		return a.errorMessage(c, fiber.StatusBadRequest, "Customer ID is required", i18n.KeyMissingParameter)
 	}
 
-	err := a.customerService.DeleteCustomer(c.Context(), customerID)
+	deletion, err := a.customerLifecycle.Delete(c.Context(), requestTenant(c), customerID)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
 	}
+	a.customerCache.Invalidate(c.Context(), customerID)
 
-	return c.SendStatus(fiber.StatusNoContent)
+	return c.JSON(deletion)
 }
 
 // addPaymentMethod handles adding payment methods to customers
 func (a *App) addPaymentMethod(c *fiber.Ctx) error {
 	customerID := c.Params("customerId")
 	if customerID == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Customer ID is required",
-		})
+		return a.errorMessage(c, fiber.StatusBadRequest, "Customer ID is required", i18n.KeyMissingParameter)
 	}
 
 	var request stripe.PaymentMethodRequest
 	if err := c.BodyParser(&request); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
 	}
 
 	// Set the customer ID from the URL parameter
 	request.Customer = customerID
 
+	if request.Type == "sofort" {
+		a.useDeprecated(c, deprecatedSofort)
+	}
+
+	if err := a.checkMetadata(c, metadata.ResourcePaymentMethod, request.Metadata); err != nil {
+		return a.errorResponse(c, metadataErrorStatus(err), err)
+	}
+
 	paymentMethod, err := a.customerService.AddPaymentMethod(c.Context(), &request)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(paymentMethod)
+	// Card fingerprints are only known once the card is attached
+	if err := a.checkPaymentMethodBlocked(c.Context(), requestTenant(c), paymentMethod); err != nil {
+		return a.errorResponse(c, blocklistErrorStatus(err), err)
+	}
+
+	vaulted, err := a.vaultPaymentMethod(c.Context(), paymentMethod)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(vaulted)
 }
 
 // listPaymentMethods handles listing payment methods for a customer
 func (a *App) listPaymentMethods(c *fiber.Ctx) error {
 	customerID := c.Params("customerId")
 	if customerID == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Customer ID is required",
-		})
+		return a.errorMessage(c, fiber.StatusBadRequest, "Customer ID is required", i18n.KeyMissingParameter)
 	}
 
-	paymentMethods, err := a.customerService.ListPaymentMethods(c.Context(), customerID, 0)
+	page, err := pageRequest(c)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	paymentMethods, err := a.customerService.ListPaymentMethods(c.Context(), customerID, page)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+	paymentMethods, hasMore, nextCursor := trimPage(paymentMethods, page, func(paymentMethod *stripe.PaymentMethod) string {
+		return paymentMethod.ID
+	})
+
+	// Retries can ask for cards that have authorized with a network token
+	// first; the page is reordered, the cursor stays that of the provider's order
+	if c.Query("prefer") == "tokenized" {
+		tokenized, err := a.repository.ListTokenizedPaymentMethods(c.Context(), customerID)
+		if err != nil {
+			return a.errorResponse(c, fiber.StatusInternalServerError, err)
+		}
+		paymentMethods = stripe.PreferTokenized(paymentMethods, tokenized)
+	}
+
+	vaulted := make([]*vaultedPaymentMethod, len(paymentMethods))
+	for i, paymentMethod := range paymentMethods {
+		if vaulted[i], err = a.vaultPaymentMethod(c.Context(), paymentMethod); err != nil {
+			return a.errorResponse(c, fiber.StatusInternalServerError, err)
+		}
 	}
 
-	return c.JSON(paymentMethods)
+	return c.JSON(pageResponse(vaulted, hasMore, nextCursor))
 }
 
 // getPaymentMethod handles payment method retrieval
 func (a *App) getPaymentMethod(c *fiber.Ctx) error {
 	paymentMethodID := c.Params("id")
 	if paymentMethodID == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Payment method ID is required",
-		})
+		return a.errorMessage(c, fiber.StatusBadRequest, "Payment method ID is required", i18n.KeyMissingParameter)
 	}
 
-	paymentMethod, err := a.customerService.GetPaymentMethod(c.Context(), paymentMethodID)
+	providerID, err := a.resolvePaymentMethod(c.Context(), paymentMethodID)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return a.errorResponse(c, vaultErrorStatus(err), err)
 	}
 
-	return c.JSON(paymentMethod)
+	paymentMethod, err := a.customerService.GetPaymentMethod(c.Context(), providerID)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusNotFound, err)
+	}
+
+	vaulted, err := a.vaultPaymentMethod(c.Context(), paymentMethod)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(vaulted)
 }
 
 // detachPaymentMethod handles payment method detachment
 func (a *App) detachPaymentMethod(c *fiber.Ctx) error {
 	paymentMethodID := c.Params("id")
 	if paymentMethodID == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Payment method ID is required",
-		})
+		return a.errorMessage(c, fiber.StatusBadRequest, "Payment method ID is required", i18n.KeyMissingParameter)
 	}
 
-	err := a.customerService.DetachPaymentMethod(c.Context(), paymentMethodID)
+	providerID, err := a.resolvePaymentMethod(c.Context(), paymentMethodID)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return a.errorResponse(c, vaultErrorStatus(err), err)
+	}
+
+	err = a.customerService.DetachPaymentMethod(c.Context(), providerID)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+	if err := a.paymentMethods.Forget(c.Context(), requestTenant(c), c.Params("customerId"), providerID); err != nil {
+		requestid.Logf(c.Context(), "Failed to update payment method preference after detaching %s: %v", providerID, err)
+	}
+
+	if vault.IsToken(paymentMethodID) {
+		if err := a.vault.Revoke(c.Context(), paymentMethodID); err != nil {
+			return a.errorResponse(c, fiber.StatusInternalServerError, err)
+		}
 	}
 
 	return c.SendStatus(fiber.StatusNoContent)
@@ -279,18 +1485,124 @@ func (a *App) detachPaymentMethod(c *fiber.Ctx) error {
 
 // createCharge handles charge creation
 func (a *App) createCharge(c *fiber.Ctx) error {
-	var request stripe.ChargeRequest
+	// Annotate execution traces so charge latency can be isolated
+	ctx, task := trace.NewTask(c.Context(), "createCharge")
+	defer task.End()
+
+	var request struct {
+		stripe.ChargeRequest
+		CustomFields map[string]string `json:"custom_fields,omitempty"`
+		Provider     string            `json:"provider,omitempty"`
+	}
 	if err := c.BodyParser(&request); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+	if err := a.selectBodyProvider(c, request.Provider); err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	if c.QueryBool("dry_run") {
+		return a.dryRunCharge(c, &request.ChargeRequest, request.CustomFields)
+	}
+
+	return a.submitCharge(ctx, c, &request.ChargeRequest, request.CustomFields)
+}
+
+// submitCharge checks, routes and screens a charge request before creating
+// the charge
+func (a *App) submitCharge(ctx context.Context, c *fiber.Ctx, request *stripe.ChargeRequest, customFields map[string]string) error {
+	if err := a.checkMetadata(c, metadata.ResourceCharge, request.Metadata); err != nil {
+		return a.errorResponse(c, metadataErrorStatus(err), err)
 	}
 
-	charge, err := a.chargeService.CreateCharge(c.Context(), &request)
+	// Custom field values are kept in metadata and printed on the receipt
+	fieldMetadata, rendered, err := a.collectCustomFields(c, request.Metadata, customFields)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return a.errorResponse(c, customFieldsErrorStatus(err), err)
+	}
+	request.Metadata = fieldMetadata
+	request.Description = customfields.ReceiptDescription(request.Description, rendered)
+
+	// Descriptors and receipt emails default to the tenant's receipt settings
+	if err := a.applyReceiptSettings(ctx, requestTenant(c), request); err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	if request.Source != "" {
+		a.useDeprecated(c, deprecatedChargeSource)
+	}
+
+	// Charges may reference a vaulted payment method by its pmt_ token
+	for _, field := range []*string{&request.PaymentMethod, &request.Source} {
+		if *field == "" {
+			continue
+		}
+		resolved, err := a.resolvePaymentMethod(ctx, *field)
+		if err != nil {
+			return a.errorResponse(c, vaultErrorStatus(err), err)
+		}
+		*field = resolved
+	}
+
+	// Customer charges naming no payment method use the customer's default,
+	// falling back to their backups when it is declined
+	if err := a.paymentMethods.ApplyPreference(ctx, requestTenant(c), request); err != nil {
+		return a.errorResponse(c, paymentMethodPreferenceErrorStatus(err), err)
+	}
+
+	// Currency routes send charges to local acquirers where one is configured,
+	// unless the request selects a provider
+	decision := a.router.Route(ctx, request.Currency)
+	if err := routeError(decision); err != nil {
+		return a.errorResponse(c, fiber.StatusUnprocessableEntity, err)
+	}
+
+	// Tenants with a hard-stop budget cannot charge past their monthly limit
+	amount, err := money.ResolveAmount(request.Amount, request.AmountDecimal, request.Currency)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+	tenantID := requestTenant(c)
+	if err := a.budgets.CheckCharge(ctx, tenantID, request.Currency, amount); err != nil {
+		return a.errorResponse(c, budgetErrorStatus(err), err)
+	}
+
+	// Cards shared by many customers or charged back are blocked outright
+	screening := a.fraudScreening(c, request)
+	if _, err := a.fingerprints.Check(ctx, tenantID, screening.CardFingerprint, screening.CustomerID); err != nil {
+		return a.errorResponse(c, chargeErrorStatus(err), err)
+	}
+
+	// Customer credit is spent before the payment method is charged, and
+	// charges it covers entirely charge nothing
+	application, err := a.customerBalances.Apply(ctx, tenantID, request)
+	if err != nil {
+		return a.errorResponse(c, customerBalanceErrorStatus(err), err)
+	}
+	if application != nil && application.Covered() {
+		return c.Status(fiber.StatusCreated).JSON(application)
+	}
+
+	// Suspicious charges are held for review instead of being created
+	charge, review, err := a.fraud.CreateCharge(ctx, screening, request)
+	if err != nil {
+		a.reverseCustomerBalance(ctx, application)
+		return a.errorResponse(c, chargeErrorStatus(err), err)
+	}
+	if review != nil {
+		a.reverseCustomerBalance(ctx, application)
+		return c.Status(fiber.StatusAccepted).JSON(review)
+	}
+	if application != nil {
+		if err := a.customerBalances.Settle(ctx, application, charge.ID); err != nil {
+			requestid.Logf(ctx, "Failed to link balance transaction %s to charge %s: %v", application.Transaction.ID, charge.ID, err)
+		}
+	}
+
+	// Uncaptured charges are tracked now rather than when the webhook arrives,
+	// so they can be captured straight away
+	if _, err := a.authorizations.TrackStripeCharge(ctx, tenantID, charge); err != nil {
+		requestid.Logf(ctx, "Failed to track authorization of charge %s: %v", charge.ID, err)
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(charge)
@@ -300,51 +1612,92 @@ func (a *App) createCharge(c *fiber.Ctx) error {
 func (a *App) getCharge(c *fiber.Ctx) error {
 	chargeID := c.Params("id")
 	if chargeID == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Charge ID is required",
-		})
+		return a.errorMessage(c, fiber.StatusBadRequest, "Charge ID is required", i18n.KeyMissingParameter)
 	}
 
-	charge, err := a.chargeService.GetCharge(c.Context(), chargeID)
+	charge, err := a.chargeCache.Get(c.Context(), chargeID)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return a.errorResponse(c, fiber.StatusNotFound, err)
 	}
 
 	return c.JSON(charge)
 }
 
-// listCharges handles listing charges
+// listCharges handles listing charges, from the provider unless the request
+// filters on more than the customer or asks for an export
 func (a *App) listCharges(c *fiber.Ctx) error {
+	// Exports and filters beyond the customer read the mirrored charges
+	if c.Query("format") != "" {
+		return a.exportCharges(c)
+	}
+	if chargeSearchRequested(c) {
+		return a.searchCharges(c)
+	}
+
 	customerID := c.Query("customer_id")
-	
-	charges, err := a.chargeService.ListCharges(c.Context(), customerID, 0)
+
+	page, err := pageRequest(c)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
 	}
 
-	return c.JSON(charges)
+	charges, err := a.chargeService.ListCharges(c.Context(), customerID, page)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	charges, hasMore, nextCursor := trimPage(charges, page, func(charge *stripe.Charge) string { return charge.ID })
+	return c.JSON(pageResponse(charges, hasMore, nextCursor))
+}
+
+// scheduledRefundRequest is a refund, optionally issued at a later time
+type scheduledRefundRequest struct {
+	stripe.RefundRequest
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty"`
 }
 
 // createRefund handles refund creation
 func (a *App) createRefund(c *fiber.Ctx) error {
-	var request stripe.RefundRequest
-	if err := c.BodyParser(&request); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
+	var body scheduledRefundRequest
+	if err := c.BodyParser(&body); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+	request := body.RefundRequest
+
+	if c.QueryBool("dry_run") {
+		return a.dryRunRefund(c, &request)
+	}
+
+	// Refunds scheduled for later are queued as a batch of one for a worker
+	if body.ScheduledAt != nil && body.ScheduledAt.After(time.Now()) {
+		return a.queueRefunds(c, refundbatches.CreateRequest{
+			Refunds:     []stripe.RefundRequest{request},
+			ScheduledAt: body.ScheduledAt,
 		})
 	}
 
-	refund, err := a.refundService.CreateRefund(c.Context(), &request)
+	if err := a.checkMetadata(c, metadata.ResourceRefund, request.Metadata); err != nil {
+		return a.errorResponse(c, metadataErrorStatus(err), err)
+	}
+
+	// Only captured charges can be refunded; webhooks record the transition
+	err := a.chargeStates.Allows(c.Context(), request.ChargeID, chargestate.StatePartiallyRefunded, chargestate.StateRefunded)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return a.errorResponse(c, chargeStateErrorStatus(err), err)
 	}
 
+	refund, approval, err := a.refundGuard.CreateRefund(c.Context(), refundActor(c), &request)
+	if err != nil {
+		return a.errorResponse(c, refundErrorStatus(err), err)
+	}
+
+	if approval != nil {
+		// Refund volume looks anomalous or the refund is over the approval
+		// threshold, so the refund waits for step-up approval
+		return c.Status(fiber.StatusAccepted).JSON(approval)
+	}
+	a.chargeCache.Invalidate(c.Context(), request.ChargeID)
+
 	return c.Status(fiber.StatusCreated).JSON(refund)
 }
 
@@ -352,16 +1705,12 @@ func (a *App) createRefund(c *fiber.Ctx) error {
 func (a *App) getRefund(c *fiber.Ctx) error {
 	refundID := c.Params("id")
 	if refundID == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Refund ID is required",
-		})
+		return a.errorMessage(c, fiber.StatusBadRequest, "Refund ID is required", i18n.KeyMissingParameter)
 	}
 
 	refund, err := a.refundService.GetRefund(c.Context(), refundID)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return a.errorResponse(c, fiber.StatusNotFound, err)
 	}
 
 	return c.JSON(refund)
@@ -371,23 +1720,29 @@ func (a *App) getRefund(c *fiber.Ctx) error {
 func (a *App) listRefunds(c *fiber.Ctx) error {
 	chargeID := c.Query("charge_id")
 	if chargeID == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Charge ID is required",
-		})
+		return a.errorMessage(c, fiber.StatusBadRequest, "Charge ID is required", i18n.KeyMissingParameter)
 	}
-	
-	refunds, err := a.refundService.ListRefunds(c.Context(), chargeID, 100)
+
+	page, err := pageRequest(c)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	refunds, err := a.refundService.ListRefunds(c.Context(), chargeID, page)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
 	}
 
-	return c.JSON(refunds)
+	refunds, hasMore, nextCursor := trimPage(refunds, page, func(refund *stripe.Refund) string { return refund.ID })
+	return c.JSON(pageResponse(refunds, hasMore, nextCursor))
 }
 
-// Run starts the application
-func (a *App) Run(port string) error {
+// Run starts the application in its run mode. The public server always
+// listens so orchestrators can probe health; it serves the API only when
+// the instance has the API role.
+func (a *App) Run(port, adminPort string) error {
+	log.Printf("Running as %s", a.runMode)
+
 	// Start the server
 	go func() {
 		if err := a.fiberApp.Listen(":" + port); err != nil {
@@ -395,25 +1750,213 @@ func (a *App) Run(port string) error {
 		}
 	}()
 
+	// Start the admin server on its own port so it is never exposed with the public API
+	if a.adminApp != nil {
+		go func() {
+			log.Printf("Starting admin server on port %s", adminPort)
+			if err := a.adminApp.Listen(":" + adminPort); err != nil {
+				log.Fatalf("Failed to start admin server: %v", err)
+			}
+		}()
+	}
+
+	if a.grpcServer != nil {
+		listener, err := net.Listen("tcp", ":"+a.grpcConfig.Port)
+		if err != nil {
+			return fmt.Errorf("failed to listen on gRPC port: %w", err)
+		}
+		go func() {
+			log.Printf("Starting gRPC server on port %s", a.grpcConfig.Port)
+			if err := a.grpcServer.Serve(listener); err != nil {
+				log.Fatalf("Failed to start gRPC server: %v", err)
+			}
+		}()
+	}
+
+	// Background jobs run only on instances with the worker role. Catch-up
+	// resumes from the event log, so it is cancelled if it outlasts the
+	// shutdown grace.
+	jobCtx, cancelJobs := context.WithCancel(context.Background())
+	defer cancelJobs()
+	stopWorkers := func() {}
+	if a.runMode.RunsWorkers() {
+		stopWorkers = a.startWorkers(jobCtx)
+	}
+
+	// Persist deprecated usage counts and measure database latency so
+	// saturated webhook processing is shed. Webhook secret rotations started
+	// on other instances are picked up where webhooks are received.
+	stopDeprecations := func(ctx context.Context) {}
+	stopBackpressure := func() {}
+	stopSecretRotation := func() {}
+	if a.runMode.ServesAPI() {
+		stopDeprecations = a.deprecations.Start(time.Minute)
+		stopBackpressure = a.backpressure.Start(func(ctx context.Context) error {
+			return a.connectionManager.GetYugabytePool().Ping(ctx)
+		})
+		stopSecretRotation = a.webhookSecrets.Start()
+	}
+
+	// Analytics events logged by webhook handlers are inserted in the background
+	stopAnalytics := func() {}
+	if a.analyticsWriter != nil {
+		stopAnalytics = a.analyticsWriter.Start()
+	}
+
 	// Wait for interrupt signal
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
+	// Fail readiness. Load balancers only route to API instances, so only
+	// they keep serving until they are taken out of rotation.
+	a.drain.StartDraining()
+	if a.runMode.ServesAPI() {
+		log.Printf("Draining for %s before shutdown...", a.drainConfig.PreStopDelay)
+		time.Sleep(a.drainConfig.PreStopDelay)
+	}
+
 	log.Println("Shutting down server...")
 
-	// Graceful shutdown
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	// Graceful shutdown: stop accepting connections and let in-flight work finish
+	ctx, cancel := context.WithTimeout(context.Background(), a.drainConfig.GracePeriod)
 	defer cancel()
 
 	if err := a.fiberApp.ShutdownWithContext(ctx); err != nil {
-		return fmt.Errorf("server forced to shutdown: %v", err)
+		log.Printf("Server forced to shutdown: %v", err)
+	}
+	if a.grpcServer != nil {
+		a.stopGRPC(ctx)
+	}
+
+	if err := a.drain.Wait(ctx); err != nil {
+		log.Printf("Shutdown grace period expired: %v", err)
+	}
+	cancelJobs()
+
+	stopWorkers()
+	stopBackpressure()
+	stopSecretRotation()
+
+	// Release components such as consumers once nothing is in flight
+	if err := a.drain.Shutdown(ctx); err != nil {
+		log.Printf("Shutdown hooks failed: %v", err)
+	}
+
+	// Insert the analytics events still queued before ClickHouse is closed
+	stopAnalytics()
+
+	// The event producer outlives the consumers, whose commands emit events
+	if a.eventPublisher != nil {
+		if err := a.eventPublisher.Close(); err != nil {
+			log.Printf("Failed to close event publisher: %v", err)
+		}
 	}
 
+	if a.adminApp != nil {
+		if err := a.adminApp.ShutdownWithContext(ctx); err != nil {
+			log.Printf("Admin server forced to shutdown: %v", err)
+		}
+	}
+
+	stopDeprecations(ctx)
+	a.connectionManager.Close()
+
 	log.Println("Server exited")
 	return nil
 }
 
+// stopGRPC lets in-flight gRPC calls finish, cancelling them once the
+// shutdown grace period expires
+func (a *App) stopGRPC(ctx context.Context) {
+	stopped := make(chan struct{})
+	go func() {
+		a.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		log.Printf("gRPC server forced to shutdown: %v", ctx.Err())
+		a.grpcServer.Stop()
+	}
+}
+
+// startWorkers starts the background jobs of the worker role and returns a
+// func stopping them
+func (a *App) startWorkers(ctx context.Context) (stop func()) {
+	// Fetch events missed while the service was down
+	if os.Getenv("WEBHOOK_CATCHUP_ON_STARTUP") != "false" {
+		done := a.drain.Begin(drain.KindJob)
+		go func() {
+			defer done()
+			a.catchUpAfterDowntime(ctx)
+		}()
+	}
+
+	// Refund unclaimed funds past their window when enabled
+	stopAutoRefunds := a.autoRefunds.Start()
+
+	// Remind customers about invoices approaching or past their due date
+	stopInvoiceReminders := a.invoicing.Start()
+	stopBlocklistSync := a.blocklist.Start()
+
+	// Deactivate payment links past their expiry
+	stopPaymentLinkExpiry := a.paymentLinks.Start()
+
+	// Run scheduled jobs: dunning of failed subscription payments, voiding
+	// authorizations left uncaptured until close to their expiry and nightly
+	// reconciliation against the provider
+	stopJobs := a.jobs.Start()
+
+	// Remind about dispute evidence deadlines
+	stopDisputeReminders := a.disputeEvidence.Start()
+
+	// Retry usage records that failed to push to the provider
+	stopUsageRetry := a.usage.Start()
+
+	// Retry dead-lettered webhook events and commands with backoff
+	stopDeadLetterRetry := a.deadLetters.Start()
+
+	// Send events to tenants' webhook endpoints, retrying with backoff
+	stopWebhookDelivery := a.merchantWebhooks.Start()
+
+	// Publish relayed events stored in the outbox
+	stopRelayOutbox := a.relayOutbox.Start()
+
+	// Build queued offboarding export archives
+	stopOffboardingExports := a.offboarding.Start()
+
+	// Issue refund batches and scheduled refunds once they are due
+	stopRefundBatches := a.refundBatches.Start()
+
+	// Purge deleted customers once their retention period ends
+	stopCustomerPurge := a.customerLifecycle.Start()
+
+	// Execute commands other services publish to Kafka
+	a.startCommandConsumer()
+
+	// Write relayed events to ClickHouse when analytics are ingested from Kafka
+	a.startAnalyticsIngestion()
+
+	return func() {
+		stopAutoRefunds()
+		stopInvoiceReminders()
+		stopJobs()
+		stopBlocklistSync()
+		stopPaymentLinkExpiry()
+		stopDisputeReminders()
+		stopUsageRetry()
+		stopDeadLetterRetry()
+		stopWebhookDelivery()
+		stopRelayOutbox()
+		stopOffboardingExports()
+		stopRefundBatches()
+		stopCustomerPurge()
+	}
+}
+
 // initTracing initializes OpenTelemetry tracing
 func initTracing() error {
 	ctx := context.Background()
@@ -439,6 +1982,7 @@ func initTracing() error {
 	tp := sdktrace.NewTracerProvider(
 		sdktrace.WithBatcher(exporter),
 		sdktrace.WithResource(res),
+		sdktrace.WithSpanProcessor(requestid.SpanProcessor{}),
 	)
 	otel.SetTracerProvider(tp)
 
@@ -446,22 +1990,38 @@ func initTracing() error {
 }
 
 func main() {
+	// Settings in the environment override those in CONFIG_FILE, and secrets
+	// can be read from files or Vault. Invalid settings stop the service.
+	loader := config.NewLoader(os.Getenv("CONFIG_FILE"), settings)
+	if err := loader.Load(context.Background()); err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
 	// Get port from environment variable or use default
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
 
+	adminPort := os.Getenv("ADMIN_PORT")
+	if adminPort == "" {
+		adminPort = "9090"
+	}
+
 	// Initialize tracing
 	if err := initTracing(); err != nil {
 		log.Printf("Warning: Failed to initialize tracing: %v", err)
 	}
 
-	// Create and run the application
-	app := NewApp()
-	
+	// Create and run the application. Rate limits and routing are reloaded
+	// from the config file on SIGHUP.
+	app := NewApp(loader)
+	loader.OnReload(app.applyConfig)
+	stopConfigWatch := loader.Watch()
+	defer stopConfigWatch()
+
 	log.Printf("Starting Payments API server on port %s", port)
-	if err := app.Run(port); err != nil {
+	if err := app.Run(port, adminPort); err != nil {
 		log.Fatalf("Failed to run application: %v", err)
 	}
 }
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+
+	"apis/payments/services/i18n"
+	"apis/payments/services/merchantwebhooks"
+	"apis/payments/services/tenantcredentials"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// merchantWebhookErrorStatus maps outgoing webhook errors to HTTP status codes
+func merchantWebhookErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return fiber.StatusNotFound
+	case errors.Is(err, merchantwebhooks.ErrInvalidEndpoint):
+		return fiber.StatusBadRequest
+	case errors.Is(err, tenantcredentials.ErrEncryptionNotConfigured):
+		return fiber.StatusServiceUnavailable
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// createWebhookEndpoint handles registering an endpoint for the tenant's
+// events. The signing secret is only returned in this response.
+func (a *App) createWebhookEndpoint(c *fiber.Ctx) error {
+	var request merchantwebhooks.CreateEndpointRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	endpoint, err := a.merchantWebhooks.CreateEndpoint(c.Context(), requestTenant(c), request)
+	if err != nil {
+		return a.errorResponse(c, merchantWebhookErrorStatus(err), err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(endpoint)
+}
+
+// listWebhookEndpoints handles listing the tenant's webhook endpoints
+func (a *App) listWebhookEndpoints(c *fiber.Ctx) error {
+	endpoints, err := a.merchantWebhooks.ListEndpoints(c.Context(), requestTenant(c))
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(fiber.Map{"data": endpoints})
+}
+
+// getWebhookEndpoint handles retrieving one of the tenant's webhook endpoints
+func (a *App) getWebhookEndpoint(c *fiber.Ctx) error {
+	endpoint, err := a.merchantWebhooks.GetEndpoint(c.Context(), requestTenant(c), c.Params("id"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return a.errorMessage(c, fiber.StatusNotFound, "Webhook endpoint not found", i18n.KeyNotFound)
+	}
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(endpoint)
+}
+
+// updateWebhookEndpoint handles changing or disabling a webhook endpoint
+func (a *App) updateWebhookEndpoint(c *fiber.Ctx) error {
+	var request merchantwebhooks.UpdateEndpointRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	endpoint, err := a.merchantWebhooks.UpdateEndpoint(c.Context(), requestTenant(c), c.Params("id"), request)
+	if errors.Is(err, sql.ErrNoRows) {
+		return a.errorMessage(c, fiber.StatusNotFound, "Webhook endpoint not found", i18n.KeyNotFound)
+	}
+	if err != nil {
+		return a.errorResponse(c, merchantWebhookErrorStatus(err), err)
+	}
+
+	return c.JSON(endpoint)
+}
+
+// deleteWebhookEndpoint handles removing a webhook endpoint and its deliveries
+func (a *App) deleteWebhookEndpoint(c *fiber.Ctx) error {
+	deleted, err := a.merchantWebhooks.DeleteEndpoint(c.Context(), requestTenant(c), c.Params("id"))
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+	if !deleted {
+		return a.errorMessage(c, fiber.StatusNotFound, "Webhook endpoint not found", i18n.KeyNotFound)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// listWebhookDeliveries handles listing an endpoint's deliveries, optionally
+// filtered by status
+func (a *App) listWebhookDeliveries(c *fiber.Ctx) error {
+	deliveries, err := a.merchantWebhooks.ListDeliveries(c.Context(), requestTenant(c), c.Params("id"), c.Query("status"), c.QueryInt("limit", 100))
+	if errors.Is(err, sql.ErrNoRows) {
+		return a.errorMessage(c, fiber.StatusNotFound, "Webhook endpoint not found", i18n.KeyNotFound)
+	}
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(fiber.Map{"data": deliveries})
+}
+
+// getWebhookDelivery handles retrieving a delivery with the log of its attempts
+func (a *App) getWebhookDelivery(c *fiber.Ctx) error {
+	delivery, err := a.merchantWebhooks.GetDelivery(c.Context(), requestTenant(c), c.Params("id"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return a.errorMessage(c, fiber.StatusNotFound, "Webhook delivery not found", i18n.KeyNotFound)
+	}
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(delivery)
+}
+
+// redeliverWebhookDelivery handles sending a delivery again now. The delivery
+// is returned with the outcome; a failed attempt is not an error response.
+func (a *App) redeliverWebhookDelivery(c *fiber.Ctx) error {
+	delivery, err := a.merchantWebhooks.Redeliver(c.Context(), requestTenant(c), c.Params("id"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return a.errorMessage(c, fiber.StatusNotFound, "Webhook delivery not found", i18n.KeyNotFound)
+	}
+	if err != nil {
+		return a.errorResponse(c, merchantWebhookErrorStatus(err), err)
+	}
+
+	return c.JSON(delivery)
+}
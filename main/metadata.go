@@ -0,0 +1,95 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+
+	"apis/payments/services/i18n"
+	"apis/payments/services/metadata"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// checkMetadata validates metadata for a new resource against the tenant's schema
+func (a *App) checkMetadata(c *fiber.Ctx, resource string, values map[string]string) error {
+	return a.metadataSchemas.Validate(c.Context(), requestTenant(c), resource, values)
+}
+
+// checkMetadataUpdate validates metadata sent to update a resource against the tenant's schema
+func (a *App) checkMetadataUpdate(c *fiber.Ctx, resource string, values map[string]string) error {
+	return a.metadataSchemas.ValidateUpdate(c.Context(), requestTenant(c), resource, values)
+}
+
+// metadataErrorStatus maps metadata validation errors to HTTP status codes
+func metadataErrorStatus(err error) int {
+	if errors.Is(err, metadata.ErrInvalidMetadata) || errors.Is(err, metadata.ErrInvalidSchema) {
+		return fiber.StatusBadRequest
+	}
+	return fiber.StatusInternalServerError
+}
+
+// listMetadataSchemas handles listing the tenant's metadata schemas
+func (a *App) listMetadataSchemas(c *fiber.Ctx) error {
+	registrations, err := a.metadataSchemas.ListSchemas(c.Context(), requestTenant(c))
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(registrations)
+}
+
+// getMetadataSchema handles metadata schema retrieval for a resource type
+func (a *App) getMetadataSchema(c *fiber.Ctx) error {
+	resource := c.Params("resource")
+	if resource == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Resource is required", i18n.KeyMissingParameter)
+	}
+
+	registration, err := a.metadataSchemas.GetSchema(c.Context(), requestTenant(c), resource)
+	if errors.Is(err, sql.ErrNoRows) {
+		return a.errorMessage(c, fiber.StatusNotFound, "Metadata schema not found", i18n.KeyNotFound)
+	}
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(registration)
+}
+
+// registerMetadataSchema handles registering or replacing a resource type's metadata schema
+func (a *App) registerMetadataSchema(c *fiber.Ctx) error {
+	resource := c.Params("resource")
+	if resource == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Resource is required", i18n.KeyMissingParameter)
+	}
+
+	var schema metadata.Schema
+	if err := c.BodyParser(&schema); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	registration, err := a.metadataSchemas.RegisterSchema(c.Context(), requestTenant(c), resource, &schema)
+	if err != nil {
+		return a.errorResponse(c, metadataErrorStatus(err), err)
+	}
+
+	return c.JSON(registration)
+}
+
+// deleteMetadataSchema handles removing a resource type's metadata schema
+func (a *App) deleteMetadataSchema(c *fiber.Ctx) error {
+	resource := c.Params("resource")
+	if resource == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Resource is required", i18n.KeyMissingParameter)
+	}
+
+	deleted, err := a.metadataSchemas.DeleteSchema(c.Context(), requestTenant(c), resource)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+	if !deleted {
+		return a.errorMessage(c, fiber.StatusNotFound, "Metadata schema not found", i18n.KeyNotFound)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"apis/payments/services/i18n"
+	"apis/payments/services/offboarding"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// offboardingErrorStatus maps offboarding export errors to HTTP status codes
+func offboardingErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return fiber.StatusNotFound
+	case errors.Is(err, offboarding.ErrInvalidRecipientKey), errors.Is(err, offboarding.ErrDestinationRequired):
+		return fiber.StatusBadRequest
+	case errors.Is(err, offboarding.ErrNotCompleted), errors.Is(err, offboarding.ErrMigrationRequested):
+		return fiber.StatusConflict
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// listOffboardingExports handles listing a tenant's offboarding exports
+func (a *App) listOffboardingExports(c *fiber.Ctx) error {
+	exports, err := a.offboarding.List(c.Context(), c.Params("tenantId"), c.QueryInt("limit", 100))
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(fiber.Map{"data": exports})
+}
+
+// createOffboardingExport handles queueing an export of a tenant's data
+func (a *App) createOffboardingExport(c *fiber.Ctx) error {
+	var req offboarding.CreateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	export, err := a.offboarding.Create(c.Context(), c.Params("tenantId"), req)
+	if err != nil {
+		return a.errorResponse(c, offboardingErrorStatus(err), err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(export)
+}
+
+// getOffboardingExport handles retrieving an offboarding export
+func (a *App) getOffboardingExport(c *fiber.Ctx) error {
+	export, err := a.offboarding.Get(c.Context(), c.Params("id"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return a.errorMessage(c, fiber.StatusNotFound, "Offboarding export not found", i18n.KeyNotFound)
+	}
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(export)
+}
+
+// downloadOffboardingArchive handles downloading a completed export's
+// encrypted archive
+func (a *App) downloadOffboardingArchive(c *fiber.Ctx) error {
+	export, archive, err := a.offboarding.Archive(c.Context(), c.Params("id"))
+	if err != nil {
+		return a.errorResponse(c, offboardingErrorStatus(err), err)
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMEOctetStream)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.pmxa"`, export.ID))
+	c.Set("X-Archive-SHA256", export.ArchiveSHA256)
+	return c.Send(archive)
+}
+
+// requestPANMigration handles asking the provider to transfer an export's
+// card numbers to the merchant's new PSP
+func (a *App) requestPANMigration(c *fiber.Ctx) error {
+	var req offboarding.MigrationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	export, err := a.offboarding.RequestPANMigration(c.Context(), c.Params("id"), req)
+	if err != nil && export != nil {
+		// The migration hook failed; the failure is recorded and can be retried
+		return a.errorResponse(c, fiber.StatusBadGateway, err)
+	}
+	if err != nil {
+		return a.errorResponse(c, offboardingErrorStatus(err), err)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(export)
+}
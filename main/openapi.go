@@ -0,0 +1,228 @@
+package main
+
+import (
+	"net/http"
+
+	"apis/payments/services"
+	"apis/payments/services/auth"
+	"apis/payments/services/authorizations"
+	"apis/payments/services/blocklist"
+	"apis/payments/services/composite"
+	"apis/payments/services/customerbalance"
+	"apis/payments/services/customers"
+	"apis/payments/services/documents"
+	"apis/payments/services/dunning"
+	"apis/payments/services/ephemeralkeys"
+	"apis/payments/services/graphql"
+	"apis/payments/services/merchantwebhooks"
+	"apis/payments/services/openapi"
+	"apis/payments/services/paymentlinks"
+	"apis/payments/services/paymentmethods"
+	"apis/payments/services/receipts"
+	"apis/payments/services/refundbatches"
+	"apis/payments/services/simulator"
+	"apis/payments/services/stripe"
+	"apis/payments/services/subscriptions"
+	"apis/payments/services/usage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// apiPrefix is where API routes are mounted
+const apiPrefix = "/api/v1"
+
+// getOpenAPISpec serves the API's OpenAPI document
+func (a *App) getOpenAPISpec(c *fiber.Ctx) error {
+	return c.JSON(a.apiSpec)
+}
+
+// getAPIDocs serves Swagger UI for the OpenAPI document
+func (a *App) getAPIDocs(c *fiber.Ctx) error {
+	c.Type("html")
+	return c.SendString(openapi.SwaggerUI("Payments API", apiPrefix+"/openapi.json"))
+}
+
+// buildAPISpec builds the OpenAPI document of every registered API route,
+// with the request and response bodies of the routes described here
+func (a *App) buildAPISpec() *openapi.Document {
+	builder := openapi.NewBuilder(openapi.Info{
+		Title:       "Payments API",
+		Description: "Provider-agnostic payments: customers, payment methods, charges, refunds, subscriptions, disputes and more.",
+		Version:     "1.0.0",
+	}, apiPrefix)
+	describeAPI(builder)
+
+	var routes []openapi.Route
+	for _, route := range a.fiberApp.GetRoutes(true) {
+		routes = append(routes, openapi.Route{Method: route.Method, Path: route.Path})
+	}
+	return builder.Build(routes)
+}
+
+// describeAPI describes the bodies of API routes. Routes not described here
+// are still documented, with untyped bodies.
+func describeAPI(b *openapi.Builder) {
+	// API keys
+	b.Describe(http.MethodGet, "/api-keys", openapi.Spec{Summary: "List API keys"})
+	b.Describe(http.MethodPost, "/api-keys", openapi.Spec{Summary: "Create an API key", Request: auth.CreateKeyRequest{}, Response: auth.APIKey{}, Status: http.StatusCreated})
+	b.Describe(http.MethodPost, "/api-keys/:id/rotate", openapi.Spec{Summary: "Rotate an API key", Response: auth.APIKey{}, Status: http.StatusCreated})
+
+	// Customers
+	b.Describe(http.MethodPost, "/customers", openapi.Spec{Summary: "Create a customer", Request: stripe.CustomerRequest{}, Response: stripe.Customer{}, Status: http.StatusCreated})
+	b.Describe(http.MethodPut, "/customers/upsert", openapi.Spec{Summary: "Create or update a customer by external reference; 201 when created", Request: upsertCustomerRequest{}, Response: stripe.Customer{}})
+	b.Describe(http.MethodGet, "/customers", openapi.Spec{Summary: "List the tenant's customers, newest first", Response: stripe.Customer{}, List: true, Query: []openapi.Parameter{
+		{Name: "email", In: "query", Description: "Email, compared case-insensitively", Schema: &openapi.Schema{Type: "string"}},
+		{Name: "name_prefix", In: "query", Description: "Start of the name, compared case-insensitively", Schema: &openapi.Schema{Type: "string"}},
+		{Name: "metadata[key]", In: "query", Description: "Metadata value the customer holds for key", Schema: &openapi.Schema{Type: "string"}},
+		{Name: "created_after", In: "query", Description: "Created at or after this RFC 3339 time", Schema: &openapi.Schema{Type: "string"}},
+		{Name: "created_before", In: "query", Description: "Created before this RFC 3339 time", Schema: &openapi.Schema{Type: "string"}},
+	}})
+	b.Describe(http.MethodGet, "/customers/search", openapi.Spec{Summary: "Search the tenant's customers by ID, or the start of their email or name", Response: stripe.Customer{}, List: true, Query: []openapi.Parameter{
+		{Name: "q", In: "query", Description: "Customer ID, or start of an email or name", Required: true, Schema: &openapi.Schema{Type: "string"}},
+	}})
+	b.Describe(http.MethodGet, "/customers/:id", openapi.Spec{Summary: "Get a customer", Response: stripe.Customer{}})
+	b.Describe(http.MethodPut, "/customers/:id", openapi.Spec{Summary: "Update a customer", Request: stripe.CustomerRequest{}, Response: stripe.Customer{}})
+	b.Describe(http.MethodDelete, "/customers/:id", openapi.Spec{Summary: "Delete a customer, purged at the provider once its retention period ends", Response: customers.Deletion{}})
+	b.Describe(http.MethodPost, "/customers/:id/restore", openapi.Spec{Summary: "Restore a customer deleted within its retention period", Status: http.StatusNoContent})
+	b.Describe(http.MethodPost, "/customers/:id/erase", openapi.Spec{Summary: "Erase a customer's personal data, keeping its financial records, and detach its payment methods", Response: customers.Erasure{}})
+	b.Describe(http.MethodGet, "/customers/:id/balance", openapi.Spec{Summary: "Get a customer's credit balances with their latest transactions", Response: customerbalance.Summary{}})
+	b.Describe(http.MethodPost, "/customers/:id/balance/credits", openapi.Spec{Summary: "Issue a credit to a customer, such as a goodwill credit or a charge refunded to the balance", Request: customerbalance.CreditRequest{}, Response: customerbalance.Transaction{}, Status: http.StatusCreated})
+	b.Describe(http.MethodPost, "/customers/:id/balance/reconcile", openapi.Spec{Summary: "Match a customer's balance to their Stripe customer balance", Response: customerbalance.Reconciliation{}})
+	b.Describe(http.MethodPost, "/customers/:id/email-verification/confirm", openapi.Spec{Summary: "Confirm a customer's email", Request: verifyEmailRequest{}})
+
+	// Payment methods and setup intents
+	b.Describe(http.MethodPost, "/customers/:customerId/payment-methods", openapi.Spec{Summary: "Add a payment method", Request: stripe.PaymentMethodRequest{}, Response: vaultedPaymentMethod{}, Status: http.StatusCreated})
+	b.Describe(http.MethodGet, "/customers/:customerId/payment-methods", openapi.Spec{Summary: "List a customer's payment methods", Response: vaultedPaymentMethod{}, List: true})
+	b.Describe(http.MethodGet, "/customers/:customerId/payment-methods/default", openapi.Spec{Summary: "Get a customer's default and backup payment methods", Response: paymentmethods.Preference{}})
+	b.Describe(http.MethodPut, "/customers/:customerId/payment-methods/default", openapi.Spec{Summary: "Set a customer's default payment method, making the previous default the first backup", Request: defaultPaymentMethodRequest{}, Response: paymentmethods.Preference{}})
+	b.Describe(http.MethodPut, "/customers/:customerId/payment-methods/backups", openapi.Spec{Summary: "Set the payment methods tried, in order, when a customer's default is declined", Request: backupPaymentMethodsRequest{}, Response: paymentmethods.Preference{}})
+	b.Describe(http.MethodGet, "/customers/:customerId/payment-methods/:id", openapi.Spec{Summary: "Get a payment method", Response: vaultedPaymentMethod{}})
+	b.Describe(http.MethodDelete, "/customers/:customerId/payment-methods/:id", openapi.Spec{Summary: "Detach a payment method", Status: http.StatusNoContent})
+	b.Describe(http.MethodPost, "/customers/:customerId/setup-intents", openapi.Spec{Summary: "Create a setup intent", Request: stripe.SetupIntentRequest{}, Response: stripe.SetupIntent{}})
+	b.Describe(http.MethodGet, "/customers/:customerId/setup-intents/:id", openapi.Spec{Summary: "Get a setup intent", Response: stripe.SetupIntent{}})
+	b.Describe(http.MethodPost, "/customers/:customerId/setup-intents/:id/verify-microdeposits", openapi.Spec{Summary: "Verify an ACH bank account with its micro-deposits", Request: stripe.MicrodepositVerification{}, Response: stripe.SetupIntent{}})
+
+	// Charges and refunds
+	b.Describe(http.MethodPost, "/charges", openapi.Spec{Summary: "Create a charge", Request: stripe.ChargeRequest{}, Response: stripe.Charge{}, Status: http.StatusCreated, Deprecated: true})
+	b.Describe(http.MethodGet, "/charges/:id", openapi.Spec{Summary: "Get a charge", Response: stripe.Charge{}, Deprecated: true})
+	b.Describe(http.MethodGet, "/charges", openapi.Spec{Summary: "List charges, newest first, or export them with format", Response: stripe.Charge{}, List: true, Deprecated: true, Query: []openapi.Parameter{
+		{Name: "customer_id", In: "query", Description: "Customer the charges are for", Schema: &openapi.Schema{Type: "string"}},
+		{Name: "status", In: "query", Description: "Charge status", Schema: &openapi.Schema{Type: "string"}},
+		{Name: "currency", In: "query", Description: "Three-letter currency code", Schema: &openapi.Schema{Type: "string"}},
+		{Name: "min_amount", In: "query", Description: "Smallest amount, in the smallest currency unit", Schema: &openapi.Schema{Type: "integer"}},
+		{Name: "max_amount", In: "query", Description: "Largest amount, in the smallest currency unit", Schema: &openapi.Schema{Type: "integer"}},
+		{Name: "card_brand", In: "query", Description: "Brand of the card charged, such as visa", Schema: &openapi.Schema{Type: "string"}},
+		{Name: "card_last4", In: "query", Description: "Last four digits of the card charged", Schema: &openapi.Schema{Type: "string"}},
+		{Name: "metadata[key]", In: "query", Description: "Metadata value the charge holds for key", Schema: &openapi.Schema{Type: "string"}},
+		{Name: "created_after", In: "query", Description: "Created at or after this RFC 3339 time", Schema: &openapi.Schema{Type: "string"}},
+		{Name: "created_before", In: "query", Description: "Created before this RFC 3339 time", Schema: &openapi.Schema{Type: "string"}},
+		{Name: "format", In: "query", Description: "csv or json to stream every matching charge as a download instead of a page", Schema: &openapi.Schema{Type: "string"}},
+	}})
+	b.Describe(http.MethodGet, "/charges/:id/receipt", openapi.Spec{Summary: "Get the provider's receipt URL for a charge, or a generated PDF receipt when it has none", Response: receiptLink{}, Query: []openapi.Parameter{
+		{Name: "format", In: "query", Description: "pdf to always return a generated PDF receipt", Schema: &openapi.Schema{Type: "string"}},
+	}})
+	b.Describe(http.MethodGet, "/receipt-settings", openapi.Spec{Summary: "Get the tenant's receipt settings", Response: receipts.Settings{}})
+	b.Describe(http.MethodPut, "/receipt-settings", openapi.Spec{Summary: "Set the tenant's receipt emails, default descriptor suffix and receipt branding", Request: receipts.Settings{}, Response: receipts.Settings{}})
+	b.Describe(http.MethodGet, "/charges/:id/receipt/document", openapi.Spec{Summary: "Render a charge's receipt as a branded PDF and get its signed download URL", Response: documents.Document{}, Query: []openapi.Parameter{
+		{Name: "language", In: "query", Description: "Language to render in: en, es, fr or de; defaults to the template's", Schema: &openapi.Schema{Type: "string"}},
+	}})
+	b.Describe(http.MethodGet, "/documents/:id", openapi.Spec{Summary: "Get a rendered document with a freshly signed download URL", Response: documents.Document{}})
+	b.Describe(http.MethodGet, "/document-templates/:kind", openapi.Spec{Summary: "Get the tenant's invoice or receipt template", Response: documents.Template{}})
+	b.Describe(http.MethodPut, "/document-templates/:kind", openapi.Spec{Summary: "Set the business details, accent color, footer and language of the tenant's invoices or receipts", Request: documents.Template{}, Response: documents.Template{}})
+	b.Describe(http.MethodDelete, "/document-templates/:kind", openapi.Spec{Summary: "Remove the tenant's invoice or receipt template", Status: http.StatusNoContent})
+	b.Describe(http.MethodPost, "/charges/wallet", openapi.Spec{Summary: "Charge an Apple Pay or Google Pay payment token; 202 when held for review", Request: walletChargeRequest{}, Response: stripe.Charge{}, Status: http.StatusCreated})
+	b.Describe(http.MethodPost, "/payment-intents", openapi.Spec{Summary: "Start a Klarna or Afterpay payment the customer authorizes by redirect", Request: stripe.PaymentIntentRequest{}, Response: stripe.PaymentIntent{}, Status: http.StatusCreated})
+	b.Describe(http.MethodGet, "/payment-intents/:id", openapi.Spec{Summary: "Get a payment intent", Response: stripe.PaymentIntent{}})
+	b.Describe(http.MethodPost, "/payment-intents/:id/capture", openapi.Spec{Summary: "Capture an authorized payment intent within its capture window", Request: capturePaymentIntentRequest{}, Response: stripe.PaymentIntent{}})
+	b.Describe(http.MethodPost, "/payment-intents/:id/cancel", openapi.Spec{Summary: "Cancel a payment intent and release its authorization", Response: stripe.PaymentIntent{}})
+	b.Describe(http.MethodPost, "/wallets/domains", openapi.Spec{Summary: "Register a domain for Apple Pay and Google Pay", Request: registerWalletDomainRequest{}, Response: stripe.WalletDomain{}, Status: http.StatusCreated})
+	b.Describe(http.MethodGet, "/wallets/domains", openapi.Spec{Summary: "List wallet domains", Response: stripe.WalletDomain{}, List: true})
+	b.Describe(http.MethodPost, "/wallets/domains/:id/validate", openapi.Spec{Summary: "Verify a wallet domain with Apple Pay again", Response: stripe.WalletDomain{}})
+	b.Describe(http.MethodPost, "/refunds", openapi.Spec{Summary: "Refund a charge; 202 when the refund awaits approval or is scheduled", Request: scheduledRefundRequest{}, Response: stripe.Refund{}, Status: http.StatusCreated})
+	b.Describe(http.MethodGet, "/refunds/:id", openapi.Spec{Summary: "Get a refund", Response: stripe.Refund{}})
+	b.Describe(http.MethodGet, "/refunds", openapi.Spec{Summary: "List refunds", Response: stripe.Refund{}, List: true})
+	b.Describe(http.MethodPost, "/refunds/batch", openapi.Spec{Summary: "Queue refunds to be issued in the background", Request: refundbatches.CreateRequest{}, Response: refundbatches.Batch{}, Status: http.StatusAccepted})
+	b.Describe(http.MethodGet, "/refunds/batch/:id", openapi.Spec{Summary: "Get a refund batch with the result of each refund", Response: refundbatches.Batch{}})
+	b.Describe(http.MethodPost, "/refunds/batch/:id/cancel", openapi.Spec{Summary: "Cancel a refund batch or scheduled refund before it runs", Response: refundbatches.Batch{}})
+	b.Describe(http.MethodPost, "/composite-charges", openapi.Spec{Summary: "Charge an order across several payment methods", Request: composite.CreateChargeRequest{}, Response: composite.Charge{}, Status: http.StatusCreated})
+	b.Describe(http.MethodGet, "/composite-charges/:id", openapi.Spec{Summary: "Get a composite charge", Response: composite.Charge{}})
+
+	// Plans and subscriptions
+	b.Describe(http.MethodPost, "/plans", openapi.Spec{Summary: "Create a plan", Request: stripe.PlanRequest{}, Response: stripe.SubscriptionPlan{}, Status: http.StatusCreated})
+	b.Describe(http.MethodGet, "/plans", openapi.Spec{Summary: "List plans", Response: stripe.SubscriptionPlan{}, List: true})
+	b.Describe(http.MethodGet, "/plans/:id", openapi.Spec{Summary: "Get a plan", Response: stripe.SubscriptionPlan{}})
+	b.Describe(http.MethodPut, "/plans/:id", openapi.Spec{Summary: "Rename, archive or restore a plan", Request: stripe.PlanUpdate{}, Response: stripe.SubscriptionPlan{}})
+	b.Describe(http.MethodDelete, "/plans/:id", openapi.Spec{Summary: "Archive a plan", Response: stripe.SubscriptionPlan{}})
+	b.Describe(http.MethodPost, "/subscriptions", openapi.Spec{Summary: "Subscribe a customer to a plan", Request: stripe.SubscriptionRequest{}, Response: stripe.Subscription{}, Status: http.StatusCreated})
+	b.Describe(http.MethodGet, "/subscriptions", openapi.Spec{Summary: "List subscriptions", Response: stripe.Subscription{}, List: true})
+	b.Describe(http.MethodGet, "/subscriptions/:id", openapi.Spec{Summary: "Get a subscription", Response: stripe.Subscription{}})
+	b.Describe(http.MethodPut, "/subscriptions/:id", openapi.Spec{Summary: "Change a subscription's plan, quantity or metadata, with or without proration", Request: subscriptions.UpdateRequest{}, Response: stripe.Subscription{}})
+	b.Describe(http.MethodPost, "/subscriptions/:id/preview", openapi.Spec{Summary: "Preview the prorated amount of a plan or quantity change", Request: subscriptions.PreviewRequest{}, Response: stripe.ProrationPreview{}})
+	b.Describe(http.MethodPost, "/subscriptions/:id/cancel", openapi.Spec{Summary: "Cancel a subscription now or at period end", Request: subscriptions.CancelRequest{}, Response: stripe.Subscription{}})
+	b.Describe(http.MethodPost, "/subscriptions/:id/reactivate", openapi.Spec{Summary: "Undo a cancellation at period end", Response: stripe.Subscription{}})
+	b.Describe(http.MethodPost, "/subscriptions/:id/usage", openapi.Spec{Summary: "Report metered usage; 202 when it is not pushed to the provider yet", Request: usage.ReportRequest{}, Response: usage.Record{}, Status: http.StatusCreated})
+	b.Describe(http.MethodGet, "/subscriptions/:id/usage", openapi.Spec{Summary: "Get metered consumption over a window", Response: usage.Consumption{}})
+	b.Describe(http.MethodPost, "/subscriptions/:id/scheduled-change", openapi.Spec{Summary: "Change plan at the end of the period", Request: stripe.PlanChangeRequest{}, Response: stripe.Subscription{}})
+
+	// Dunning
+	b.Describe(http.MethodGet, "/dunning", openapi.Spec{Summary: "List dunning cases, newest first", Response: dunning.Case{}, List: true})
+	b.Describe(http.MethodGet, "/dunning/:id", openapi.Spec{Summary: "Get a dunning case", Response: dunning.Case{}})
+	b.Describe(http.MethodPost, "/dunning/:id/retry", openapi.Spec{Summary: "Retry an open case's invoice payment now", Response: dunning.Case{}})
+	b.Describe(http.MethodGet, "/authorizations", openapi.Spec{Summary: "List the tenant's authorizations, newest first", Response: authorizations.Authorization{}, List: true})
+	b.Describe(http.MethodGet, "/authorizations/:id", openapi.Spec{Summary: "Get the authorization of an uncaptured charge", Response: authorizations.Authorization{}})
+	b.Describe(http.MethodPost, "/authorizations/:id/capture", openapi.Spec{Summary: "Capture all or part of an authorization, optionally keeping the rest open", Request: services.CaptureChargeRequest{}, Response: authorizationResult{}})
+	b.Describe(http.MethodPost, "/authorizations/:id/void", openapi.Spec{Summary: "Release an authorization without capturing it", Response: authorizationResult{}})
+
+	// Disputes
+	b.Describe(http.MethodGet, "/disputes", openapi.Spec{Summary: "List disputes", Response: []*stripe.Dispute{}})
+	b.Describe(http.MethodGet, "/disputes/:id", openapi.Spec{Summary: "Get a dispute", Response: stripe.Dispute{}})
+
+	// Invoices
+	b.Describe(http.MethodPost, "/invoices", openapi.Spec{Summary: "Create an invoice", Request: stripe.CreateInvoiceRequest{}, Response: stripe.Invoice{}, Status: http.StatusCreated})
+	b.Describe(http.MethodGet, "/invoices/:id", openapi.Spec{Summary: "Get an invoice", Response: stripe.Invoice{}})
+	b.Describe(http.MethodPost, "/invoices/:id/finalize", openapi.Spec{Summary: "Finalize a draft invoice", Response: stripe.Invoice{}})
+	b.Describe(http.MethodPost, "/invoices/:id/pay", openapi.Spec{Summary: "Pay an open invoice", Response: stripe.Invoice{}})
+	b.Describe(http.MethodPost, "/invoices/:id/void", openapi.Spec{Summary: "Void an open invoice", Response: stripe.Invoice{}})
+	b.Describe(http.MethodGet, "/invoices/:id/document", openapi.Spec{Summary: "Render an invoice as a branded PDF and get its signed download URL", Response: documents.Document{}, Query: []openapi.Parameter{
+		{Name: "language", In: "query", Description: "Language to render in: en, es, fr or de; defaults to the template's", Schema: &openapi.Schema{Type: "string"}},
+	}})
+
+	// Connected accounts
+	b.Describe(http.MethodPost, "/accounts", openapi.Spec{Summary: "Create a connected account", Request: stripe.CreateAccountRequest{}, Response: stripe.ConnectedAccount{}, Status: http.StatusCreated})
+	b.Describe(http.MethodGet, "/accounts", openapi.Spec{Summary: "List connected accounts", Response: stripe.ConnectedAccount{}, List: true})
+	b.Describe(http.MethodGet, "/accounts/:id", openapi.Spec{Summary: "Get a connected account", Response: stripe.ConnectedAccount{}})
+	b.Describe(http.MethodPost, "/accounts/:id/onboarding-links", openapi.Spec{Summary: "Create an onboarding link", Response: stripe.AccountLink{}, Status: http.StatusCreated})
+	b.Describe(http.MethodPost, "/accounts/:id/transfers", openapi.Spec{Summary: "Transfer funds to a connected account", Request: stripe.TransferRequest{}, Response: stripe.Transfer{}, Status: http.StatusCreated})
+	b.Describe(http.MethodGet, "/accounts/:id/transfers", openapi.Spec{Summary: "List transfers", Response: stripe.Transfer{}, List: true})
+
+	// Payment links
+	b.Describe(http.MethodPost, "/payment-links", openapi.Spec{Summary: "Create a payment link", Request: paymentlinks.CreateRequest{}, Response: paymentlinks.PaymentLink{}, Status: http.StatusCreated})
+	b.Describe(http.MethodGet, "/payment-links/:id", openapi.Spec{Summary: "Get a payment link", Response: paymentlinks.PaymentLink{}})
+	b.Describe(http.MethodPost, "/payment-links/:id/deactivate", openapi.Spec{Summary: "Deactivate a payment link", Response: paymentlinks.PaymentLink{}})
+
+	// Ephemeral keys and fraud tooling
+	b.Describe(http.MethodPost, "/ephemeral-keys", openapi.Spec{Summary: "Issue an ephemeral key for a frontend client", Request: ephemeralkeys.IssueRequest{}, Response: ephemeralkeys.Key{}, Status: http.StatusCreated})
+	b.Describe(http.MethodGet, "/blocklist", openapi.Spec{Summary: "List blocklist entries", Response: []*blocklist.Entry{}})
+	b.Describe(http.MethodPost, "/blocklist", openapi.Spec{Summary: "Block a value", Request: addBlocklistEntryRequest{}, Response: blocklist.Entry{}, Status: http.StatusCreated})
+	b.Describe(http.MethodGet, "/card-fingerprints/:fingerprint", openapi.Spec{Summary: "Assess a card fingerprint's sharing and chargebacks", Response: cardFingerprintResponse{}})
+
+	// Outgoing webhooks
+	b.Describe(http.MethodPost, "/webhook-endpoints", openapi.Spec{Summary: "Register an endpoint for the tenant's events; the signing secret is only returned now", Request: merchantwebhooks.CreateEndpointRequest{}, Response: merchantwebhooks.Endpoint{}, Status: http.StatusCreated})
+	b.Describe(http.MethodGet, "/webhook-endpoints", openapi.Spec{Summary: "List the tenant's webhook endpoints", Response: []*merchantwebhooks.Endpoint{}})
+	b.Describe(http.MethodGet, "/webhook-endpoints/:id", openapi.Spec{Summary: "Get a webhook endpoint", Response: merchantwebhooks.Endpoint{}})
+	b.Describe(http.MethodPut, "/webhook-endpoints/:id", openapi.Spec{Summary: "Change or disable a webhook endpoint", Request: merchantwebhooks.UpdateEndpointRequest{}, Response: merchantwebhooks.Endpoint{}})
+	b.Describe(http.MethodDelete, "/webhook-endpoints/:id", openapi.Spec{Summary: "Remove a webhook endpoint and its deliveries", Status: http.StatusNoContent})
+	b.Describe(http.MethodGet, "/webhook-endpoints/:id/deliveries", openapi.Spec{Summary: "List an endpoint's deliveries, newest first", Response: []*merchantwebhooks.Delivery{}, Query: []openapi.Parameter{
+		{Name: "status", In: "query", Description: "pending, succeeded or failed", Schema: &openapi.Schema{Type: "string"}},
+		{Name: "limit", In: "query", Description: "Deliveries returned, at most 100", Schema: &openapi.Schema{Type: "integer"}},
+	}})
+	b.Describe(http.MethodGet, "/webhook-deliveries/:id", openapi.Spec{Summary: "Get a webhook delivery with the log of its attempts", Response: merchantwebhooks.Delivery{}})
+	b.Describe(http.MethodPost, "/webhook-deliveries/:id/redeliver", openapi.Spec{Summary: "Send a webhook delivery again now", Response: merchantwebhooks.Delivery{}})
+
+	// Simulator
+	b.Describe(http.MethodGet, "/simulator/cards", openapi.Spec{Summary: "List the simulator's test card numbers and what charging them does", Response: []simulator.Card{}})
+	b.Describe(http.MethodPost, "/simulator/charges/:id/authenticate", openapi.Spec{Summary: "Complete or fail the 3D Secure authentication of a simulated charge", Request: authenticateSimulatorChargeRequest{}, Response: services.Charge{}})
+
+	// GraphQL
+	b.Describe(http.MethodPost, "/graphql", openapi.Spec{Summary: "Query customers, charges, subscriptions and disputes with GraphQL", Request: graphql.Request{}, Response: graphql.Response{}})
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+
+	"apis/payments/services/stripe"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// defaultPageLimit is the page size of provider lists when ?limit= is not set
+const defaultPageLimit = 10
+
+// errInvalidPageLimit is returned for page sizes outside 1 to stripe.MaxPageLimit
+var errInvalidPageLimit = errors.New("limit must be between 1 and 100")
+
+// pageRequest reads the page of a provider list a request asks for: ?limit=
+// objects after the ?starting_after= object. ?cursor= is accepted for
+// starting_after, as it is what responses return as next_cursor. The page
+// fetches one object more than the limit, which tells whether there are more.
+func pageRequest(c *fiber.Ctx) (stripe.Page, error) {
+	limit := c.QueryInt("limit", defaultPageLimit)
+	if limit < 1 || limit > stripe.MaxPageLimit {
+		return stripe.Page{}, errInvalidPageLimit
+	}
+
+	cursor := c.Query("starting_after", c.Query("cursor"))
+	return stripe.Page{Limit: int64(limit) + 1, StartingAfter: cursor}, nil
+}
+
+// trimPage cuts the extra object fetched by pageRequest off a page, and
+// returns whether there are more objects along with the cursor of the next page
+func trimPage[T any](items []T, page stripe.Page, id func(T) string) ([]T, bool, string) {
+	limit := int(page.Limit) - 1
+	if len(items) <= limit {
+		return items, false, ""
+	}
+
+	items = items[:limit]
+	return items, true, id(items[limit-1])
+}
+
+// pageResponse is the body of a provider list response
+func pageResponse(data any, hasMore bool, nextCursor string) fiber.Map {
+	response := fiber.Map{"data": data, "has_more": hasMore}
+	if nextCursor != "" {
+		response["next_cursor"] = nextCursor
+	}
+	return response
+}
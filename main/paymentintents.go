@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"apis/payments/services"
+	"apis/payments/services/i18n"
+	"apis/payments/services/metadata"
+	"apis/payments/services/money"
+	"apis/payments/services/stripe"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// errUnsupportedBNPL is returned for a BNPL payment the routed provider
+// doesn't accept in the requested currency
+var errUnsupportedBNPL = errors.New("payment method type is not supported by the provider in this currency")
+
+// capturePaymentIntentRequest optionally captures part of an authorization
+type capturePaymentIntentRequest struct {
+	Amount int64 `json:"amount,omitempty"` // Captures the full amount when zero
+}
+
+// createPaymentIntent starts a buy-now-pay-later payment. The customer
+// authorizes it at the returned redirect_url.
+func (a *App) createPaymentIntent(c *fiber.Ctx) error {
+	var request stripe.PaymentIntentRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	if err := a.checkMetadata(c, metadata.ResourceCharge, request.Metadata); err != nil {
+		return a.errorResponse(c, metadataErrorStatus(err), err)
+	}
+
+	// Lenders are offered only where the routed provider accepts them
+	decision := a.router.Route(c.Context(), request.Currency)
+	if err := routeError(decision); err != nil {
+		return a.errorResponse(c, fiber.StatusUnprocessableEntity, err)
+	}
+	capabilities, err := services.Capabilities(decision.Provider)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusUnprocessableEntity, err)
+	}
+	if _, ok := capabilities.BNPLMethod(request.PaymentMethodType, request.Currency); !ok {
+		return a.errorResponse(c, fiber.StatusUnprocessableEntity, fmt.Errorf("%w: %s", errUnsupportedBNPL, request.PaymentMethodType))
+	}
+
+	// Tenants with a hard-stop budget cannot charge past their monthly limit
+	amount, err := money.ResolveAmount(request.Amount, request.AmountDecimal, request.Currency)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+	if err := a.budgets.CheckCharge(c.Context(), requestTenant(c), request.Currency, amount); err != nil {
+		return a.errorResponse(c, budgetErrorStatus(err), err)
+	}
+
+	intent, err := a.chargeService.CreatePaymentIntent(c.Context(), &request)
+	if err != nil {
+		return a.errorResponse(c, paymentIntentErrorStatus(err), err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(intent)
+}
+
+// getPaymentIntent handles payment intent retrieval
+func (a *App) getPaymentIntent(c *fiber.Ctx) error {
+	intent, err := a.chargeService.GetPaymentIntent(c.Context(), c.Params("id"))
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusNotFound, err)
+	}
+
+	return c.JSON(intent)
+}
+
+// capturePaymentIntent captures an authorized payment intent
+func (a *App) capturePaymentIntent(c *fiber.Ctx) error {
+	var request capturePaymentIntentRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+		}
+	}
+
+	intent, err := a.chargeService.CapturePaymentIntent(c.Context(), c.Params("id"), request.Amount)
+	if err != nil {
+		return a.errorResponse(c, paymentIntentErrorStatus(err), err)
+	}
+
+	return c.JSON(intent)
+}
+
+// cancelPaymentIntent cancels a payment intent, releasing its authorization
+func (a *App) cancelPaymentIntent(c *fiber.Ctx) error {
+	intent, err := a.chargeService.CancelPaymentIntent(c.Context(), c.Params("id"))
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	return c.JSON(intent)
+}
+
+// paymentIntentErrorStatus maps payment intent errors to HTTP statuses
+func paymentIntentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, stripe.ErrDateOfBirthRequired), errors.Is(err, stripe.ErrShippingRequired):
+		return fiber.StatusUnprocessableEntity
+	case errors.Is(err, stripe.ErrNotCapturable), errors.Is(err, stripe.ErrCaptureWindowExpired):
+		return fiber.StatusConflict
+	default:
+		return chargeErrorStatus(err)
+	}
+}
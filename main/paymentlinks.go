@@ -0,0 +1,83 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+
+	"apis/payments/services/i18n"
+	"apis/payments/services/paymentlinks"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// paymentLinkErrorStatus maps payment link errors to HTTP status codes
+func paymentLinkErrorStatus(err error) int {
+	if errors.Is(err, sql.ErrNoRows) {
+		return fiber.StatusNotFound
+	}
+	return fiber.StatusBadRequest
+}
+
+// createPaymentLink handles creating a shareable payment link
+func (a *App) createPaymentLink(c *fiber.Ctx) error {
+	var request paymentlinks.CreateRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	link, err := a.paymentLinks.Create(c.Context(), &request)
+	if err != nil {
+		return a.errorResponse(c, paymentLinkErrorStatus(err), err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(link)
+}
+
+// listPaymentLinks handles listing payment links, optionally only active ones
+func (a *App) listPaymentLinks(c *fiber.Ctx) error {
+	links, err := a.paymentLinks.List(c.Context(), paymentlinks.Filter{
+		ActiveOnly: c.QueryBool("active"),
+		Limit:      c.QueryInt("limit"),
+	})
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(fiber.Map{"data": links})
+}
+
+// getPaymentLink handles retrieving a payment link with its conversion totals
+func (a *App) getPaymentLink(c *fiber.Ctx) error {
+	link, err := a.paymentLinks.Get(c.Context(), c.Params("id"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return a.errorMessage(c, fiber.StatusNotFound, "Payment link not found", i18n.KeyNotFound)
+	}
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(link)
+}
+
+// listPaymentLinkConversions handles listing the checkouts paid through a payment link
+func (a *App) listPaymentLinkConversions(c *fiber.Ctx) error {
+	conversions, err := a.paymentLinks.Conversions(c.Context(), c.Params("id"), c.QueryInt("limit"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return a.errorMessage(c, fiber.StatusNotFound, "Payment link not found", i18n.KeyNotFound)
+	}
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(fiber.Map{"data": conversions})
+}
+
+// deactivatePaymentLink handles deactivating a payment link
+func (a *App) deactivatePaymentLink(c *fiber.Ctx) error {
+	link, err := a.paymentLinks.Deactivate(c.Context(), c.Params("id"))
+	if err != nil {
+		return a.errorResponse(c, paymentLinkErrorStatus(err), err)
+	}
+
+	return c.JSON(link)
+}
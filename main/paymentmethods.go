@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+
+	"apis/payments/services/i18n"
+	"apis/payments/services/paymentmethods"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// defaultPaymentMethodRequest is the body of a request to set a customer's
+// default payment method
+type defaultPaymentMethodRequest struct {
+	PaymentMethod string `json:"payment_method"`
+}
+
+// backupPaymentMethodsRequest is the body of a request to order a customer's
+// backup payment methods
+type backupPaymentMethodsRequest struct {
+	BackupPaymentMethods []string `json:"backup_payment_methods"`
+}
+
+// paymentMethodPreferenceErrorStatus maps payment method preference errors
+// to HTTP status codes
+func paymentMethodPreferenceErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, paymentmethods.ErrInvalidPreference), errors.Is(err, paymentmethods.ErrNotAttached):
+		return fiber.StatusUnprocessableEntity
+	default:
+		return fiber.StatusBadRequest
+	}
+}
+
+// getPaymentMethodPreference handles retrieving a customer's default and
+// backup payment methods
+func (a *App) getPaymentMethodPreference(c *fiber.Ctx) error {
+	preference, err := a.paymentMethods.Get(c.Context(), requestTenant(c), c.Params("customerId"))
+	if err != nil {
+		return a.errorResponse(c, paymentMethodPreferenceErrorStatus(err), err)
+	}
+
+	return c.JSON(preference)
+}
+
+// setDefaultPaymentMethod handles making an attached payment method the
+// customer's default. The previous default becomes the first backup.
+func (a *App) setDefaultPaymentMethod(c *fiber.Ctx) error {
+	var request defaultPaymentMethodRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+	if request.PaymentMethod == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Payment method is required", i18n.KeyMissingParameter)
+	}
+
+	paymentMethodID, err := a.resolvePaymentMethod(c.Context(), request.PaymentMethod)
+	if err != nil {
+		return a.errorResponse(c, vaultErrorStatus(err), err)
+	}
+
+	customerID := c.Params("customerId")
+	preference, err := a.paymentMethods.SetDefault(c.Context(), requestTenant(c), customerID, paymentMethodID)
+	if err != nil {
+		return a.errorResponse(c, paymentMethodPreferenceErrorStatus(err), err)
+	}
+	a.customerCache.Invalidate(c.Context(), customerID)
+
+	return c.JSON(preference)
+}
+
+// setBackupPaymentMethods handles replacing the payment methods tried, in
+// order, when the customer's default is declined
+func (a *App) setBackupPaymentMethods(c *fiber.Ctx) error {
+	var request backupPaymentMethodsRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	backups := make([]string, len(request.BackupPaymentMethods))
+	for i, backup := range request.BackupPaymentMethods {
+		resolved, err := a.resolvePaymentMethod(c.Context(), backup)
+		if err != nil {
+			return a.errorResponse(c, vaultErrorStatus(err), err)
+		}
+		backups[i] = resolved
+	}
+
+	preference, err := a.paymentMethods.SetBackups(c.Context(), requestTenant(c), c.Params("customerId"), backups)
+	if err != nil {
+		return a.errorResponse(c, paymentMethodPreferenceErrorStatus(err), err)
+	}
+
+	return c.JSON(preference)
+}
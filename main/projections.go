@@ -0,0 +1,43 @@
+package main
+
+import (
+	"apis/payments/services/projections"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// listChargeRows handles charge listing from the read model
+func (a *App) listChargeRows(c *fiber.Ctx) error {
+	filter := projections.ChargeFilter{
+		TenantID:   requestTenant(c),
+		CustomerID: c.Query("customer_id"),
+		Status:     c.Query("status"),
+		Limit:      c.QueryInt("limit"),
+		Offset:     c.QueryInt("offset"),
+	}
+
+	rows, err := a.projections.ListCharges(c.Context(), filter)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(rows)
+}
+
+// rebuildChargeRows handles reprojecting every charge into the read model
+func (a *App) rebuildChargeRows(c *fiber.Ctx) error {
+	projected, err := a.projections.Rebuild(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":      err.Error(),
+			"request_id": requestID(c),
+			"projected":  projected,
+			"batch":      a.projections.BatchStats(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"projected": projected,
+		"batch":     a.projections.BatchStats(),
+	})
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+
+	"apis/payments/services/i18n"
+	"apis/payments/services/tenantcredentials"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// providerCredentialsErrorStatus maps provider credential errors to HTTP status codes
+func providerCredentialsErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return fiber.StatusNotFound
+	case errors.Is(err, tenantcredentials.ErrInvalidCredentials):
+		return fiber.StatusBadRequest
+	case errors.Is(err, tenantcredentials.ErrVerificationFailed):
+		return fiber.StatusUnprocessableEntity
+	case errors.Is(err, tenantcredentials.ErrEncryptionNotConfigured):
+		return fiber.StatusServiceUnavailable
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// credentialActor identifies who is changing a tenant's credentials
+func credentialActor(c *fiber.Ctx) tenantcredentials.Actor {
+	return tenantcredentials.Actor{
+		APIKeyID:   requestAPIKey(c),
+		OperatorID: c.Get("X-Operator-ID"),
+	}
+}
+
+// listProviderCredentials handles listing the tenant's provider credentials
+func (a *App) listProviderCredentials(c *fiber.Ctx) error {
+	credentials, err := a.providerCredentials.List(c.Context(), requestTenant(c))
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data":      credentials,
+		"providers": tenantcredentials.Providers(),
+	})
+}
+
+// getProviderCredential handles retrieving the tenant's masked credentials for a provider
+func (a *App) getProviderCredential(c *fiber.Ctx) error {
+	credential, err := a.providerCredentials.Get(c.Context(), requestTenant(c), c.Params("provider"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return a.errorMessage(c, fiber.StatusNotFound, "Provider credentials not found", i18n.KeyNotFound)
+	}
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(credential)
+}
+
+// saveProviderCredential handles verifying and storing the tenant's credentials for a provider
+func (a *App) saveProviderCredential(c *fiber.Ctx) error {
+	var request struct {
+		Fields map[string]string `json:"fields"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	credential, err := a.providerCredentials.Save(c.Context(), credentialActor(c), requestTenant(c), c.Params("provider"), request.Fields)
+	if err != nil {
+		return a.errorResponse(c, providerCredentialsErrorStatus(err), err)
+	}
+	a.tenantGateways.Invalidate(requestTenant(c), c.Params("provider"))
+
+	return c.JSON(credential)
+}
+
+// rotateProviderCredential handles replacing secret fields of the tenant's credentials for a provider
+func (a *App) rotateProviderCredential(c *fiber.Ctx) error {
+	var request struct {
+		Fields map[string]string `json:"fields"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	credential, err := a.providerCredentials.Rotate(c.Context(), credentialActor(c), requestTenant(c), c.Params("provider"), request.Fields)
+	if errors.Is(err, sql.ErrNoRows) {
+		return a.errorMessage(c, fiber.StatusNotFound, "Provider credentials not found", i18n.KeyNotFound)
+	}
+	if err != nil {
+		return a.errorResponse(c, providerCredentialsErrorStatus(err), err)
+	}
+	a.tenantGateways.Invalidate(requestTenant(c), c.Params("provider"))
+
+	return c.JSON(credential)
+}
+
+// deleteProviderCredential handles removing the tenant's credentials for a provider
+func (a *App) deleteProviderCredential(c *fiber.Ctx) error {
+	deleted, err := a.providerCredentials.Delete(c.Context(), credentialActor(c), requestTenant(c), c.Params("provider"))
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+	if !deleted {
+		return a.errorMessage(c, fiber.StatusNotFound, "Provider credentials not found", i18n.KeyNotFound)
+	}
+	a.tenantGateways.Invalidate(requestTenant(c), c.Params("provider"))
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// listProviderCredentialAudit handles listing changes to the tenant's credentials for a provider
+func (a *App) listProviderCredentialAudit(c *fiber.Ctx) error {
+	entries, err := a.providerCredentials.Audit(c.Context(), requestTenant(c), c.Params("provider"), c.QueryInt("limit", 100))
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(fiber.Map{"data": entries})
+}
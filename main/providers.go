@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"apis/payments/services"
+	"apis/payments/services/routing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	// errProviderNotServed is returned when a REST request selects a provider
+	// other than the one its payments go through; the gRPC API serves the
+	// other enabled providers
+	errProviderNotServed = errors.New("provider is not served by the REST API")
+	// errConflictingProvider is returned when a request's provider field and
+	// X-Payment-Provider header name different providers
+	errConflictingProvider = errors.New("provider field and X-Payment-Provider header name different providers")
+)
+
+// selectProvider routes the request's charges to the provider its
+// X-Payment-Provider header names, which must be enabled. Requests naming
+// none are routed by currency.
+func (a *App) selectProvider(c *fiber.Ctx) error {
+	provider, err := a.providers.Resolve(c.Get(services.ProviderHeader))
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+	if provider != "" {
+		routing.Bind(c.Context(), provider)
+	}
+	return c.Next()
+}
+
+// selectBodyProvider routes the request's charges to the provider its body
+// names, which must agree with the X-Payment-Provider header if both are set
+func (a *App) selectBodyProvider(c *fiber.Ctx, requested string) error {
+	provider, err := a.providers.Resolve(requested)
+	if err != nil || provider == "" {
+		return err
+	}
+	if selected, ok := routing.SelectedProvider(c.Context()); ok && selected != provider {
+		return errConflictingProvider
+	}
+
+	routing.Bind(c.Context(), provider)
+	return nil
+}
+
+// routeError returns why a charge cannot be made along a routing decision,
+// or nil when it can
+func routeError(decision *routing.Decision) error {
+	switch {
+	case decision.Provider == vaultProvider:
+		return nil
+	case decision.Reason == routing.ReasonRequested:
+		return fmt.Errorf("%w: %s", errProviderNotServed, decision.Provider)
+	default:
+		return errUnroutedProvider
+	}
+}
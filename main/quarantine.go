@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"apis/payments/services/i18n"
+	"apis/payments/services/quarantine"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// quarantineReleaseHeader carries the one-time token of a released mutation
+// being replayed, letting it past the quarantine gate
+const quarantineReleaseHeader = "X-Quarantine-Release"
+
+// replayedAPIKeyLocal holds the API key a replayed mutation was made with
+const replayedAPIKeyLocal = "replayed_api_key"
+
+// createQuarantineRequest places an API key or tenant into quarantine
+type createQuarantineRequest struct {
+	SubjectType string `json:"subject_type"`
+	SubjectID   string `json:"subject_id"`
+	Reason      string `json:"reason"`
+}
+
+// requestReplayer replays released mutations through the API as the caller
+// that made them. Tokens only live for the duration of a replay, so they
+// cannot be reused to bypass the gate.
+type requestReplayer struct {
+	app    *fiber.App
+	tokens sync.Map
+}
+
+// Replay sends a held mutation through the API and returns its response
+func (r *requestReplayer) Replay(ctx context.Context, mutation *quarantine.HeldMutation) (int, []byte, error) {
+	token := uuid.New().String()
+	r.tokens.Store(token, mutation)
+	defer r.tokens.Delete(token)
+
+	req, err := http.NewRequestWithContext(ctx, mutation.Method, "http://localhost"+mutation.Path, strings.NewReader(mutation.Body))
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", mutation.ContentType)
+	req.Header.Set("X-Tenant-ID", mutation.TenantID)
+	if mutation.OperatorID != "" {
+		req.Header.Set("X-Operator-ID", mutation.OperatorID)
+	}
+	req.Header.Set(quarantineReleaseHeader, token)
+
+	resp, err := r.app.Test(req, -1)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to replay request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	return resp.StatusCode, body, nil
+}
+
+// replaying reports whether a request carries a live replay token, without
+// consuming it
+func (r *requestReplayer) replaying(c *fiber.Ctx) bool {
+	token := c.Get(quarantineReleaseHeader)
+	if token == "" {
+		return false
+	}
+
+	_, ok := r.tokens.Load(token)
+	return ok
+}
+
+// replayed returns the held mutation a request replays, consuming its token
+func (r *requestReplayer) replayed(c *fiber.Ctx) *quarantine.HeldMutation {
+	token := c.Get(quarantineReleaseHeader)
+	if token == "" {
+		return nil
+	}
+
+	value, ok := r.tokens.LoadAndDelete(token)
+	if !ok {
+		return nil
+	}
+	return value.(*quarantine.HeldMutation)
+}
+
+// quarantineGate flags every request from a quarantined API key or tenant
+// and holds its mutations for release. Quarantine is checked before the
+// request is served, so a failed check rejects the request.
+func (a *App) quarantineGate(c *fiber.Ctx) error {
+	if mutation := a.replayer.replayed(c); mutation != nil {
+		c.Locals(replayedAPIKeyLocal, mutation.APIKeyID)
+		return c.Next()
+	}
+
+	caller := quarantine.Caller{
+		TenantID:   requestTenant(c),
+		APIKeyID:   requestAPIKey(c),
+		OperatorID: c.Get("X-Operator-ID"),
+	}
+
+	active, err := a.quarantine.Check(c.Context(), caller)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusServiceUnavailable, err)
+	}
+	if active == nil {
+		return c.Next()
+	}
+
+	held, err := a.quarantine.Flag(c.Context(), active, caller, &quarantine.Request{
+		Method:      c.Method(),
+		Path:        c.OriginalURL(),
+		ContentType: c.Get("Content-Type"),
+		Body:        c.Body(),
+	})
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+	if held != nil {
+		return c.Status(fiber.StatusAccepted).JSON(held)
+	}
+
+	return c.Next()
+}
+
+// quarantineErrorStatus maps quarantine errors to HTTP statuses
+func quarantineErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, quarantine.ErrNotQuarantined), errors.Is(err, quarantine.ErrNotPending):
+		return fiber.StatusNotFound
+	case errors.Is(err, quarantine.ErrAlreadyQuarantined):
+		return fiber.StatusConflict
+	case errors.Is(err, quarantine.ErrInvalidQuarantine):
+		return fiber.StatusUnprocessableEntity
+	default:
+		return fiber.StatusBadRequest
+	}
+}
+
+// listQuarantines returns the active quarantines
+func (a *App) listQuarantines(c *fiber.Ctx) error {
+	quarantines, err := a.quarantine.ListActive(c.Context())
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(quarantines)
+}
+
+// createQuarantine places an API key or tenant into quarantine
+func (a *App) createQuarantine(c *fiber.Ctx) error {
+	var request createQuarantineRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	created, err := a.quarantine.Quarantine(c.Context(), &quarantine.Quarantine{
+		SubjectType: request.SubjectType,
+		SubjectID:   request.SubjectID,
+		Reason:      request.Reason,
+		CreatedBy:   c.Get("X-Operator-ID"),
+	})
+	if err != nil {
+		return a.errorResponse(c, quarantineErrorStatus(err), err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(created)
+}
+
+// getQuarantine returns a quarantine
+func (a *App) getQuarantine(c *fiber.Ctx) error {
+	found, err := a.quarantine.Get(c.Context(), c.Params("id"))
+	if err != nil {
+		return a.errorResponse(c, quarantineErrorStatus(err), err)
+	}
+
+	return c.JSON(found)
+}
+
+// liftQuarantine ends a quarantine, leaving held mutations for review
+func (a *App) liftQuarantine(c *fiber.Ctx) error {
+	lifted, err := a.quarantine.Lift(c.Context(), c.Params("id"), c.Get("X-Operator-ID"))
+	if err != nil {
+		return a.errorResponse(c, quarantineErrorStatus(err), err)
+	}
+
+	return c.JSON(lifted)
+}
+
+// listQuarantineActivity returns the flagged requests made under a quarantine
+func (a *App) listQuarantineActivity(c *fiber.Ctx) error {
+	activity, err := a.quarantine.ListActivity(c.Context(), c.Params("id"), c.QueryInt("limit", 0))
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(activity)
+}
+
+// listHeldMutations returns held mutations, pending ones by default
+func (a *App) listHeldMutations(c *fiber.Ctx) error {
+	mutations, err := a.quarantine.ListHeld(c.Context(), c.Query("status", quarantine.MutationPending))
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(mutations)
+}
+
+// getHeldMutation returns a held mutation
+func (a *App) getHeldMutation(c *fiber.Ctx) error {
+	mutation, err := a.quarantine.GetHeld(c.Context(), c.Params("id"))
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusNotFound, err)
+	}
+
+	return c.JSON(mutation)
+}
+
+// releaseHeldMutation executes a held mutation and returns it with its response
+func (a *App) releaseHeldMutation(c *fiber.Ctx) error {
+	mutation, err := a.quarantine.Release(c.Context(), c.Params("id"), c.Get("X-Operator-ID"))
+	if err != nil {
+		return a.errorResponse(c, quarantineErrorStatus(err), err)
+	}
+
+	return c.JSON(mutation)
+}
+
+// rejectHeldMutation discards a held mutation
+func (a *App) rejectHeldMutation(c *fiber.Ctx) error {
+	mutation, err := a.quarantine.Reject(c.Context(), c.Params("id"), c.Get("X-Operator-ID"))
+	if err != nil {
+		return a.errorResponse(c, quarantineErrorStatus(err), err)
+	}
+
+	return c.JSON(mutation)
+}
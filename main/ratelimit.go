@@ -0,0 +1,60 @@
+package main
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"apis/payments/services/auth"
+	"apis/payments/services/ratelimit"
+	"apis/payments/services/requestid"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// rateLimit limits each API key, or each tenant for other callers, to its
+// per-endpoint token buckets. Every response carries the bucket's state in
+// X-RateLimit-* headers; requests over the limit get 429 with Retry-After.
+// Released quarantine mutations were counted when they were held.
+func (a *App) rateLimit(c *fiber.Ctx) error {
+	if !a.rateLimits.Enabled() || a.replayer.replaying(c) {
+		return c.Next()
+	}
+
+	path := strings.TrimPrefix(c.Path(), "/api/v1")
+	decision, err := a.rateLimits.Allow(c.Context(), rateLimitSubject(c), c.Method(), path)
+	if err != nil {
+		// An unreachable limiter must not take payments down with it
+		requestid.Logf(c.Context(), "Rate limiter unavailable, admitting request: %v", err)
+		return c.Next()
+	}
+
+	c.Set("X-RateLimit-Limit", strconv.Itoa(decision.Limit))
+	c.Set("X-RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+	c.Set("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil(decision.Reset.Seconds()))))
+	if !decision.Allowed {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(decision.RetryAfter.Seconds()))))
+		return a.errorResponse(c, fiber.StatusTooManyRequests, ratelimit.ErrRateLimited)
+	}
+
+	return c.Next()
+}
+
+// rateLimitSubject names whose buckets a request draws from
+func rateLimitSubject(c *fiber.Ctx) string {
+	if principal := requestPrincipal(c); principal != nil && principal.Type == auth.PrincipalAPIKey {
+		return "api_key:" + principal.ID
+	}
+	return "tenant:" + requestTenant(c)
+}
+
+// getRateLimitUsage reports the configured limits and how much of them each
+// caller used on this instance, optionally for one subject
+func (a *App) getRateLimitUsage(c *fiber.Ctx) error {
+	rules, defaultLimit := a.rateLimits.Rules()
+	return c.JSON(fiber.Map{
+		"default": defaultLimit,
+		"rules":   rules,
+		"usage":   a.rateLimits.Usage(c.Query("subject")),
+	})
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"apis/payments/services/i18n"
+	"apis/payments/services/money"
+	"apis/payments/services/receipts"
+	"apis/payments/services/requestid"
+	"apis/payments/services/stripe"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// applyReceiptSettings fills in what a charge request leaves to the tenant's
+// receipt settings: its default descriptor suffix, and the customer's email
+// as the receipt email when the tenant sends receipts
+func (a *App) applyReceiptSettings(ctx context.Context, tenantID string, request *stripe.ChargeRequest) error {
+	settings, err := a.receipts.Settings(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+
+	if request.StatementDescriptor == "" && request.StatementDescriptorSuffix == "" {
+		request.StatementDescriptorSuffix = settings.DescriptorSuffix
+	}
+
+	if settings.SendReceiptEmails && request.ReceiptEmail == "" && request.CustomerID != "" {
+		customer, err := a.customerCache.Get(ctx, request.CustomerID)
+		if err != nil {
+			// The charge fails on its own if the customer does not exist
+			requestid.Logf(ctx, "Failed to look up receipt email of customer %s: %v", request.CustomerID, err)
+			return nil
+		}
+		request.ReceiptEmail = customer.Email
+	}
+
+	return nil
+}
+
+// receiptErrorStatus maps receipt errors to HTTP status codes
+func receiptErrorStatus(err error) int {
+	if errors.Is(err, receipts.ErrInvalidSettings) || errors.Is(err, receipts.ErrInvalidDescriptor) {
+		return fiber.StatusBadRequest
+	}
+	return fiber.StatusInternalServerError
+}
+
+// receiptLink points to a charge's receipt hosted by its provider
+type receiptLink struct {
+	ChargeID      string `json:"charge_id"`
+	ReceiptURL    string `json:"receipt_url"`
+	ReceiptNumber string `json:"receipt_number,omitempty"`
+}
+
+// getChargeReceipt handles retrieving a charge's receipt. The provider's
+// hosted receipt URL is returned when it has one; otherwise, or with
+// ?format=pdf, a PDF receipt is generated.
+func (a *App) getChargeReceipt(c *fiber.Ctx) error {
+	chargeID := c.Params("id")
+	if chargeID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Charge ID is required", i18n.KeyMissingParameter)
+	}
+	format := c.Query("format")
+	if format != "" && format != "pdf" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "format must be pdf", i18n.KeyInvalidRequest)
+	}
+
+	charge, err := a.chargeCache.Get(c.Context(), chargeID)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusNotFound, err)
+	}
+
+	if format == "" && charge.ReceiptURL != "" {
+		return c.JSON(receiptLink{ChargeID: charge.ID, ReceiptURL: charge.ReceiptURL, ReceiptNumber: charge.ReceiptNumber})
+	}
+
+	settings, err := a.receipts.Settings(c.Context(), requestTenant(c))
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, `inline; filename="receipt-`+charge.ID+`.pdf"`)
+	return c.Send(receipts.RenderPDF(chargeReceipt(charge, settings)))
+}
+
+// chargeReceipt describes a charge for a locally generated receipt
+func chargeReceipt(charge *stripe.Charge, settings *receipts.Settings) *receipts.Receipt {
+	receipt := &receipts.Receipt{
+		Number:              charge.ReceiptNumber,
+		BusinessName:        settings.BusinessName,
+		SupportEmail:        settings.SupportEmail,
+		Description:         charge.Description,
+		Amount:              charge.AmountDisplay.Formatted,
+		Status:              charge.Status,
+		PaymentMethod:       charge.CardNetwork,
+		StatementDescriptor: charge.StatementDescriptor,
+		ReceiptEmail:        charge.ReceiptEmail,
+		PaidAt:              time.Unix(charge.Created, 0),
+	}
+	if receipt.Number == "" {
+		receipt.Number = charge.ID
+	}
+	if receipt.PaymentMethod == "" {
+		receipt.PaymentMethod = charge.PaymentMethodType
+	}
+	if charge.AmountRefunded > 0 {
+		receipt.AmountRefunded = money.Describe(charge.AmountRefunded, charge.Currency).Formatted
+	}
+	return receipt
+}
+
+// getReceiptSettings handles retrieving the tenant's receipt settings
+func (a *App) getReceiptSettings(c *fiber.Ctx) error {
+	settings, err := a.receipts.Settings(c.Context(), requestTenant(c))
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(settings)
+}
+
+// updateReceiptSettings handles saving the tenant's receipt settings
+func (a *App) updateReceiptSettings(c *fiber.Ctx) error {
+	var settings receipts.Settings
+	if err := c.BodyParser(&settings); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+	settings.TenantID = requestTenant(c)
+
+	saved, err := a.receipts.UpdateSettings(c.Context(), &settings)
+	if err != nil {
+		return a.errorResponse(c, receiptErrorStatus(err), err)
+	}
+
+	return c.JSON(saved)
+}
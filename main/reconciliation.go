@@ -0,0 +1,67 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"apis/payments/services/i18n"
+	"apis/payments/services/reconciliation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// listReconciliationRuns handles listing recent reconciliation runs, newest first
+func (a *App) listReconciliationRuns(c *fiber.Ctx) error {
+	runs, err := a.reconciliation.Runs(c.Context(), c.QueryInt("limit"))
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(fiber.Map{"data": runs})
+}
+
+// getReconciliationReport handles retrieving a run with the mismatches it found
+func (a *App) getReconciliationReport(c *fiber.Ctx) error {
+	report, err := a.reconciliation.Report(c.Context(), c.Params("id"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return a.errorMessage(c, fiber.StatusNotFound, "Reconciliation run not found", i18n.KeyNotFound)
+	}
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(report)
+}
+
+// runReconciliation handles reconciling a period immediately. Without
+// period_start and period_end the previous UTC day is reconciled.
+func (a *App) runReconciliation(c *fiber.Ctx) error {
+	var request struct {
+		PeriodStart *time.Time `json:"period_start"`
+		PeriodEnd   *time.Time `json:"period_end"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+		}
+	}
+
+	from, to := reconciliation.PreviousDay(time.Now())
+	if request.PeriodStart != nil || request.PeriodEnd != nil {
+		if request.PeriodStart == nil || request.PeriodEnd == nil {
+			return a.errorMessage(c, fiber.StatusBadRequest, "period_start and period_end must be given together", i18n.KeyMissingParameter)
+		}
+		from, to = *request.PeriodStart, *request.PeriodEnd
+	}
+
+	report, err := a.reconciliation.Reconcile(c.Context(), from, to)
+	if errors.Is(err, reconciliation.ErrRunInProgress) {
+		return a.errorResponse(c, fiber.StatusConflict, err)
+	}
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(report)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"apis/payments/services/i18n"
+	"apis/payments/services/metadata"
+	"apis/payments/services/refundbatches"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// refundBatchErrorStatus maps refund batch errors to HTTP statuses
+func refundBatchErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, refundbatches.ErrBatchNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, refundbatches.ErrNotCancelable):
+		return fiber.StatusConflict
+	case errors.Is(err, refundbatches.ErrEmptyBatch), errors.Is(err, refundbatches.ErrBatchTooLarge):
+		return fiber.StatusUnprocessableEntity
+	default:
+		return fiber.StatusBadRequest
+	}
+}
+
+// createRefundBatch handles queueing refunds to be issued in the background
+func (a *App) createRefundBatch(c *fiber.Ctx) error {
+	var request refundbatches.CreateRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	return a.queueRefunds(c, request)
+}
+
+// getRefundBatch handles retrieving a batch with its progress
+func (a *App) getRefundBatch(c *fiber.Ctx) error {
+	batchID := c.Params("id")
+	if batchID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Batch ID is required", i18n.KeyMissingParameter)
+	}
+
+	batch, err := a.refundBatches.Get(c.Context(), requestTenant(c), batchID)
+	if err != nil {
+		return a.errorResponse(c, refundBatchErrorStatus(err), err)
+	}
+
+	return c.JSON(batch)
+}
+
+// cancelRefundBatch handles canceling a batch or scheduled refund before it runs
+func (a *App) cancelRefundBatch(c *fiber.Ctx) error {
+	batchID := c.Params("id")
+	if batchID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Batch ID is required", i18n.KeyMissingParameter)
+	}
+
+	batch, err := a.refundBatches.Cancel(c.Context(), requestTenant(c), batchID)
+	if err != nil {
+		return a.errorResponse(c, refundBatchErrorStatus(err), err)
+	}
+
+	return c.JSON(batch)
+}
+
+// queueRefunds validates every refund up front, so a batch only fails item
+// by item on what can't be known until it runs, and queues them
+func (a *App) queueRefunds(c *fiber.Ctx, request refundbatches.CreateRequest) error {
+	for i := range request.Refunds {
+		refund := &request.Refunds[i]
+		if err := a.refundService.ValidateRefundRequest(refund); err != nil {
+			return a.errorResponse(c, fiber.StatusBadRequest, fmt.Errorf("refund %d: %w", i, err))
+		}
+		if err := a.checkMetadata(c, metadata.ResourceRefund, refund.Metadata); err != nil {
+			return a.errorResponse(c, metadataErrorStatus(err), fmt.Errorf("refund %d: %w", i, err))
+		}
+	}
+
+	batch, err := a.refundBatches.Create(c.Context(), refundActor(c), request)
+	if err != nil {
+		return a.errorResponse(c, refundBatchErrorStatus(err), err)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(batch)
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"strings"
+
+	"apis/payments/services/auth"
+	"apis/payments/services/i18n"
+	"apis/payments/services/refundguard"
+	"apis/payments/services/requestid"
+	"apis/payments/services/tenancy"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// defaultTenantID is used for requests that carry no tenant header
+const defaultTenantID = tenancy.DefaultTenantID
+
+// requestTenant returns the tenant a request acts for
+func requestTenant(c *fiber.Ctx) string {
+	if tenantID := c.Get("X-Tenant-ID"); tenantID != "" {
+		return tenantID
+	}
+	return defaultTenantID
+}
+
+// requestAPIKey fingerprints the API key behind a request so raw credentials
+// never reach the database. It returns "" for unauthenticated requests.
+// Released quarantine mutations keep the key they were made with.
+func requestAPIKey(c *fiber.Ctx) string {
+	if apiKeyID, ok := c.Locals(replayedAPIKeyLocal).(string); ok {
+		return apiKeyID
+	}
+	return auth.Fingerprint(strings.TrimPrefix(c.Get("Authorization"), "Bearer "))
+}
+
+// refundActor identifies the tenant, API key and operator behind a request
+func refundActor(c *fiber.Ctx) refundguard.Actor {
+	return refundguard.Actor{
+		TenantID:   requestTenant(c),
+		APIKeyID:   requestAPIKey(c),
+		OperatorID: c.Get("X-Operator-ID"),
+	}
+}
+
+// refundApprover identifies who is deciding a held refund. The operator and
+// their roles come from the authenticated principal, never from headers, so
+// a key cannot pose as someone else to approve its own refund. Without
+// authentication the X-Operator-ID header is all there is to go on, and the
+// approver holds no roles.
+func refundApprover(c *fiber.Ctx) refundguard.Actor {
+	approver := refundActor(c)
+	if principal := requestPrincipal(c); principal != nil {
+		approver.OperatorID = principal.ID
+		approver.Roles = append(append([]string{}, principal.Roles...), principal.Scopes...)
+	}
+	return approver
+}
+
+// listRefundApprovals handles listing the tenant's refunds awaiting approval
+func (a *App) listRefundApprovals(c *fiber.Ctx) error {
+	approvals, err := a.refundGuard.ListPendingApprovals(c.Context(), requestTenant(c))
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	return c.JSON(approvals)
+}
+
+// getRefundApproval handles refund approval retrieval
+func (a *App) getRefundApproval(c *fiber.Ctx) error {
+	approvalID := c.Params("id")
+	if approvalID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Approval ID is required", i18n.KeyMissingParameter)
+	}
+
+	approval, err := a.refundGuard.GetApproval(c.Context(), requestTenant(c), approvalID)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusNotFound, err)
+	}
+
+	return c.JSON(approval)
+}
+
+// approveRefund handles step-up approval of a held refund
+func (a *App) approveRefund(c *fiber.Ctx) error {
+	approvalID := c.Params("id")
+	if approvalID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Approval ID is required", i18n.KeyMissingParameter)
+	}
+
+	approval, err := a.refundGuard.ApproveRefund(c.Context(), approvalID, refundApprover(c))
+	if err != nil {
+		return a.errorResponse(c, approvalErrorStatus(err), err)
+	}
+
+	return c.JSON(approval)
+}
+
+// rejectRefund handles rejection of a held refund
+func (a *App) rejectRefund(c *fiber.Ctx) error {
+	approvalID := c.Params("id")
+	if approvalID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Approval ID is required", i18n.KeyMissingParameter)
+	}
+
+	approval, err := a.refundGuard.RejectRefund(c.Context(), approvalID, refundApprover(c))
+	if err != nil {
+		return a.errorResponse(c, approvalErrorStatus(err), err)
+	}
+
+	// Rejected legs of composite refunds are canceled
+	if err := a.composite.HandleApprovalRejected(c.Context(), approval); err != nil {
+		requestid.Logf(c.Context(), "Failed to cancel composite refund leg for approval %s: %v", approval.ID, err)
+	}
+
+	return c.JSON(approval)
+}
+
+// approvalErrorStatus maps refund approval errors to HTTP status codes
+func approvalErrorStatus(err error) int {
+	if errors.Is(err, refundguard.ErrApprovalNotFound) {
+		return fiber.StatusNotFound
+	}
+	if errors.Is(err, refundguard.ErrSelfApproval) || errors.Is(err, refundguard.ErrApproverRole) {
+		return fiber.StatusForbidden
+	}
+	if errors.Is(err, refundguard.ErrApprovalDecided) {
+		return fiber.StatusConflict
+	}
+	return fiber.StatusBadRequest
+}
+
+// refundErrorStatus maps refund creation errors to HTTP status codes
+func refundErrorStatus(err error) int {
+	if errors.Is(err, refundguard.ErrRefundPolicy) {
+		return fiber.StatusUnprocessableEntity
+	}
+	return fiber.StatusBadRequest
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"apis/payments/services/requestid"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// requestIDLocal is the fiber local holding the request ID, read by the
+// access log
+const requestIDLocal = "requestid"
+
+// assignRequestID takes the caller's X-Request-ID, or generates one when it
+// is missing or malformed, and echoes it on the response. The ID is bound to
+// the request's context so spans, log lines, error payloads and published
+// events can carry it.
+func assignRequestID(c *fiber.Ctx) error {
+	id := requestid.Resolve(c.Get(requestid.Header))
+	requestid.Bind(c.Context(), id)
+	c.Locals(requestIDLocal, id)
+	c.Set(requestid.Header, id)
+	return c.Next()
+}
+
+// requestID returns the ID of the request c serves
+func requestID(c *fiber.Ctx) string {
+	id, _ := requestid.FromContext(c.Context())
+	return id
+}
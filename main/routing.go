@@ -0,0 +1,26 @@
+package main
+
+import (
+	"time"
+
+	"apis/payments/services/i18n"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// getRoutingReport returns charge volume per provider, consolidated in the
+// base reporting currency
+func (a *App) getRoutingReport(c *fiber.Ctx) error {
+	days := c.QueryInt("days", 30)
+	if days <= 0 {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Days must be positive", i18n.KeyInvalidRequest)
+	}
+
+	to := time.Now()
+	report, err := a.router.Report(c.Context(), to.AddDate(0, 0, -days), to)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(report)
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"errors"
+
+	"apis/payments/services/i18n"
+	"apis/payments/services/stripe"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// setupIntentResponse is a setup intent and, once it has succeeded, the
+// payment method it saved
+type setupIntentResponse struct {
+	SetupIntent   *stripe.SetupIntent   `json:"setup_intent"`
+	PaymentMethod *vaultedPaymentMethod `json:"payment_method,omitempty"`
+}
+
+// createSetupIntent handles starting to save a payment method for a customer.
+// The client secret is handed to the frontend, which collects card details
+// with Stripe.js.
+func (a *App) createSetupIntent(c *fiber.Ctx) error {
+	customerID := c.Params("customerId")
+	if customerID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Customer ID is required", i18n.KeyMissingParameter)
+	}
+
+	return a.startSetupIntent(c, customerID)
+}
+
+// getSetupIntent handles retrieving one of a customer's setup intents
+func (a *App) getSetupIntent(c *fiber.Ctx) error {
+	intent, ok := a.customerSetupIntent(c, c.Params("customerId"))
+	if !ok {
+		return a.errorMessage(c, fiber.StatusNotFound, "Setup intent not found", i18n.KeyNotFound)
+	}
+
+	return c.JSON(intent)
+}
+
+// confirmSetupIntent handles confirming a customer's setup intent and
+// saving the resulting payment method
+func (a *App) confirmSetupIntent(c *fiber.Ctx) error {
+	return a.finishSetupIntent(c, c.Params("customerId"))
+}
+
+// cancelSetupIntent handles cancelling a customer's setup intent
+func (a *App) cancelSetupIntent(c *fiber.Ctx) error {
+	intent, ok := a.customerSetupIntent(c, c.Params("customerId"))
+	if !ok {
+		return a.errorMessage(c, fiber.StatusNotFound, "Setup intent not found", i18n.KeyNotFound)
+	}
+
+	canceled, err := a.customerService.CancelSetupIntent(c.Context(), intent.ID)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	return c.JSON(canceled)
+}
+
+// createClientSetupIntent starts saving a payment method for the key holder
+func (a *App) createClientSetupIntent(c *fiber.Ctx) error {
+	return a.startSetupIntent(c, clientCustomer(c))
+}
+
+// confirmClientSetupIntent confirms one of the key holder's setup intents
+func (a *App) confirmClientSetupIntent(c *fiber.Ctx) error {
+	return a.finishSetupIntent(c, clientCustomer(c))
+}
+
+// startSetupIntent creates a setup intent for a customer
+func (a *App) startSetupIntent(c *fiber.Ctx, customerID string) error {
+	var request stripe.SetupIntentRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+		}
+	}
+	request.Customer = customerID
+
+	intent, err := a.customerService.CreateSetupIntent(c.Context(), &request)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(intent)
+}
+
+// finishSetupIntent confirms a setup intent with the payment method in the
+// request body, if any, and saves the payment method once the intent has
+// succeeded. Intents the frontend confirmed with Stripe.js are posted with
+// no body. Intents still requiring an action are returned without a payment
+// method, to be confirmed again after the customer completes it.
+func (a *App) finishSetupIntent(c *fiber.Ctx, customerID string) error {
+	var request stripe.ConfirmSetupIntentRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+		}
+	}
+
+	intent, ok := a.customerSetupIntent(c, customerID)
+	if !ok {
+		return a.errorMessage(c, fiber.StatusNotFound, "Setup intent not found", i18n.KeyNotFound)
+	}
+
+	var err error
+	if request.PaymentMethod != "" && intent.Status != stripe.SetupIntentSucceeded {
+		if intent, err = a.customerService.ConfirmSetupIntent(c.Context(), intent.ID, &request); err != nil {
+			return a.errorResponse(c, fiber.StatusBadRequest, err)
+		}
+	}
+
+	return a.setupIntentResult(c, intent)
+}
+
+// verifyMicrodeposits handles verifying the ACH bank account of a
+// customer's setup intent with the micro-deposits sent to it
+func (a *App) verifyMicrodeposits(c *fiber.Ctx) error {
+	return a.checkMicrodeposits(c, c.Params("customerId"))
+}
+
+// verifyClientMicrodeposits verifies the bank account of one of the key
+// holder's setup intents
+func (a *App) verifyClientMicrodeposits(c *fiber.Ctx) error {
+	return a.checkMicrodeposits(c, clientCustomer(c))
+}
+
+// checkMicrodeposits verifies a setup intent's bank account and saves the
+// payment method once verification succeeds
+func (a *App) checkMicrodeposits(c *fiber.Ctx, customerID string) error {
+	var request stripe.MicrodepositVerification
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	intent, ok := a.customerSetupIntent(c, customerID)
+	if !ok {
+		return a.errorMessage(c, fiber.StatusNotFound, "Setup intent not found", i18n.KeyNotFound)
+	}
+
+	intent, err := a.customerService.VerifyMicrodeposits(c.Context(), intent.ID, &request)
+	if err != nil {
+		status := fiber.StatusBadRequest
+		if errors.Is(err, stripe.ErrInvalidMicrodeposits) {
+			status = fiber.StatusUnprocessableEntity
+		}
+		return a.errorResponse(c, status, err)
+	}
+
+	return a.setupIntentResult(c, intent)
+}
+
+// setupIntentResult returns a setup intent and, once it has succeeded, the
+// payment method it saved
+func (a *App) setupIntentResult(c *fiber.Ctx, intent *stripe.SetupIntent) error {
+	if intent.Status != stripe.SetupIntentSucceeded {
+		return c.JSON(setupIntentResponse{SetupIntent: intent})
+	}
+
+	paymentMethod, err := a.customerService.SetupIntentPaymentMethod(c.Context(), intent)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadGateway, err)
+	}
+
+	// Card fingerprints are only known once the card is attached
+	if err := a.checkPaymentMethodBlocked(c.Context(), requestTenant(c), paymentMethod); err != nil {
+		return a.errorResponse(c, blocklistErrorStatus(err), err)
+	}
+
+	vaulted, err := a.vaultPaymentMethod(c.Context(), paymentMethod)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(setupIntentResponse{SetupIntent: intent, PaymentMethod: vaulted})
+}
+
+// customerSetupIntent retrieves the setup intent in the route if it belongs
+// to the customer. Intents of other customers are reported as not found.
+func (a *App) customerSetupIntent(c *fiber.Ctx, customerID string) (*stripe.SetupIntent, bool) {
+	intent, err := a.customerService.GetSetupIntent(c.Context(), c.Params("id"))
+	if err != nil || intent.Customer != customerID {
+		return nil, false
+	}
+
+	return intent, true
+}
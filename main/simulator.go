@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+
+	"apis/payments/services/i18n"
+	"apis/payments/services/simulator"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// authenticateSimulatorChargeRequest completes or fails a simulated 3D Secure
+// authentication
+type authenticateSimulatorChargeRequest struct {
+	Succeed bool `json:"succeed"`
+}
+
+// simulatorErrorStatus maps simulator errors to the HTTP status the
+// simulated provider answered with
+func simulatorErrorStatus(err error) int {
+	var simulatorErr *simulator.Error
+	if errors.As(err, &simulatorErr) {
+		return simulatorErr.HTTPStatus()
+	}
+	return fiber.StatusInternalServerError
+}
+
+// simulatorDisabled answers requests to the simulator when it is not enabled
+func (a *App) simulatorDisabled(c *fiber.Ctx) error {
+	return a.errorMessage(c, fiber.StatusServiceUnavailable, "The simulator is not enabled", i18n.KeyGenericError)
+}
+
+// listSimulatorCards lists the test card numbers and what charging them does
+func (a *App) listSimulatorCards(c *fiber.Ctx) error {
+	if a.simulator == nil {
+		return a.simulatorDisabled(c)
+	}
+
+	return c.JSON(simulator.Cards())
+}
+
+// authenticateSimulatorCharge completes the 3D Secure authentication of a
+// simulated charge, as the cardholder would
+func (a *App) authenticateSimulatorCharge(c *fiber.Ctx) error {
+	if a.simulator == nil {
+		return a.simulatorDisabled(c)
+	}
+
+	request := authenticateSimulatorChargeRequest{Succeed: true}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+		}
+	}
+
+	charge, err := a.simulator.AuthenticateCharge(c.Context(), c.Params("id"), request.Succeed)
+	if err != nil {
+		return a.errorResponse(c, simulatorErrorStatus(err), err)
+	}
+
+	return c.JSON(charge)
+}
+
+// getSimulatorLatency returns the latency added to simulator calls
+func (a *App) getSimulatorLatency(c *fiber.Ctx) error {
+	if a.simulator == nil {
+		return a.simulatorDisabled(c)
+	}
+
+	return c.JSON(a.simulator.Latency())
+}
+
+// updateSimulatorLatency changes the latency added to simulator calls, e.g.
+// to test client timeouts
+func (a *App) updateSimulatorLatency(c *fiber.Ctx) error {
+	if a.simulator == nil {
+		return a.simulatorDisabled(c)
+	}
+
+	var request simulator.Latency
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+	if err := a.simulator.SetLatency(request); err != nil {
+		return a.errorResponse(c, simulatorErrorStatus(err), err)
+	}
+
+	return c.JSON(a.simulator.Latency())
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"errors"
+
+	"apis/payments/services/i18n"
+	"apis/payments/services/smartrouting"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// routingRuleRequest creates or replaces a smart routing rule override
+type routingRuleRequest struct {
+	Currency       string   `json:"currency"`
+	BINCountry     string   `json:"bin_country"`
+	MinAmount      int64    `json:"min_amount"`
+	MaxAmount      int64    `json:"max_amount"`
+	Strategy       string   `json:"strategy"`
+	Providers      []string `json:"providers"`
+	PinnedProvider string   `json:"pinned_provider"`
+	Priority       *int     `json:"priority"`
+	Enabled        *bool    `json:"enabled"`
+	Description    string   `json:"description"`
+}
+
+// routingDecisionRequest previews how a charge would be routed
+type routingDecisionRequest struct {
+	Amount     int64    `json:"amount"`
+	Currency   string   `json:"currency"`
+	BINCountry string   `json:"bin_country"`
+	Providers  []string `json:"providers"`
+}
+
+// rule builds a rule from the request, defaulting priority to 100 and
+// enabling it unless told otherwise
+func (r *routingRuleRequest) rule(id, operatorID string) *smartrouting.Rule {
+	rule := &smartrouting.Rule{
+		ID:             id,
+		Currency:       r.Currency,
+		BINCountry:     r.BINCountry,
+		MinAmount:      r.MinAmount,
+		MaxAmount:      r.MaxAmount,
+		Strategy:       r.Strategy,
+		Providers:      r.Providers,
+		PinnedProvider: r.PinnedProvider,
+		Priority:       100,
+		Enabled:        true,
+		Description:    r.Description,
+		UpdatedBy:      operatorID,
+	}
+	if r.Priority != nil {
+		rule.Priority = *r.Priority
+	}
+	if r.Enabled != nil {
+		rule.Enabled = *r.Enabled
+	}
+	return rule
+}
+
+// routingRuleErrorStatus maps smart routing errors to HTTP statuses
+func routingRuleErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, smartrouting.ErrRuleNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, smartrouting.ErrInvalidRule):
+		return fiber.StatusUnprocessableEntity
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// listRoutingRules returns every smart routing rule in the order they are matched
+func (a *App) listRoutingRules(c *fiber.Ctx) error {
+	rules, err := a.smartRouting.ListRules(c.Context())
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(fiber.Map{"data": rules})
+}
+
+// getRoutingRule returns a smart routing rule
+func (a *App) getRoutingRule(c *fiber.Ctx) error {
+	rule, err := a.smartRouting.GetRule(c.Context(), c.Params("id"))
+	if err != nil {
+		return a.errorResponse(c, routingRuleErrorStatus(err), err)
+	}
+
+	return c.JSON(rule)
+}
+
+// createRoutingRule adds a smart routing rule override
+func (a *App) createRoutingRule(c *fiber.Ctx) error {
+	var request routingRuleRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	rule, err := a.smartRouting.CreateRule(c.Context(), request.rule("", c.Get("X-Operator-ID")))
+	if err != nil {
+		return a.errorResponse(c, routingRuleErrorStatus(err), err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(rule)
+}
+
+// updateRoutingRule replaces a smart routing rule override
+func (a *App) updateRoutingRule(c *fiber.Ctx) error {
+	var request routingRuleRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	rule, err := a.smartRouting.UpdateRule(c.Context(), request.rule(c.Params("id"), c.Get("X-Operator-ID")))
+	if err != nil {
+		return a.errorResponse(c, routingRuleErrorStatus(err), err)
+	}
+
+	return c.JSON(rule)
+}
+
+// deleteRoutingRule removes a smart routing rule override
+func (a *App) deleteRoutingRule(c *fiber.Ctx) error {
+	if err := a.smartRouting.DeleteRule(c.Context(), c.Params("id")); err != nil {
+		return a.errorResponse(c, routingRuleErrorStatus(err), err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// previewRoutingDecision ranks providers for a charge without charging it
+func (a *App) previewRoutingDecision(c *fiber.Ctx) error {
+	var request routingDecisionRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+	if request.Amount <= 0 || request.Currency == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Amount and currency are required", i18n.KeyInvalidRequest)
+	}
+
+	charge := smartrouting.Charge{Amount: request.Amount, Currency: request.Currency, BINCountry: request.BINCountry}
+	decision, err := a.smartRouting.Decide(c.Context(), charge, request.Providers)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(decision)
+}
+
+// getRoutingAuthRates returns the authorization rates smart routing decides on
+func (a *App) getRoutingAuthRates(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"data": a.smartRouting.AuthRates(c.Context())})
+}
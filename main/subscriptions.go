@@ -0,0 +1,296 @@
+package main
+
+import (
+	"errors"
+
+	"apis/payments/services/i18n"
+	"apis/payments/services/requestid"
+	"apis/payments/services/stripe"
+	"apis/payments/services/subscriptions"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// getSubscription handles subscription retrieval, including any scheduled plan change
+func (a *App) getSubscription(c *fiber.Ctx) error {
+	subscriptionID := c.Params("id")
+	if subscriptionID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Subscription ID is required", i18n.KeyMissingParameter)
+	}
+
+	subscription, err := a.subscriptionService.GetSubscription(c.Context(), subscriptionID)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusNotFound, err)
+	}
+
+	return c.JSON(subscription)
+}
+
+// schedulePlanChange handles scheduling a plan change for the end of the current period
+func (a *App) schedulePlanChange(c *fiber.Ctx) error {
+	subscriptionID := c.Params("id")
+	if subscriptionID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Subscription ID is required", i18n.KeyMissingParameter)
+	}
+
+	var request stripe.PlanChangeRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	subscription, err := a.subscriptionService.SchedulePlanChange(c.Context(), subscriptionID, &request)
+	if err != nil {
+		status := fiber.StatusBadRequest
+		if errors.Is(err, stripe.ErrScheduleConflict) {
+			status = fiber.StatusConflict
+		}
+		return a.errorResponse(c, status, err)
+	}
+
+	return c.JSON(subscription)
+}
+
+// cancelPlanChange handles canceling a scheduled plan change before it applies
+func (a *App) cancelPlanChange(c *fiber.Ctx) error {
+	subscriptionID := c.Params("id")
+	if subscriptionID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Subscription ID is required", i18n.KeyMissingParameter)
+	}
+
+	subscription, err := a.subscriptionService.CancelPlanChange(c.Context(), subscriptionID)
+	if err != nil {
+		status := fiber.StatusBadRequest
+		if errors.Is(err, stripe.ErrNoScheduledChange) {
+			status = fiber.StatusNotFound
+		}
+		return a.errorResponse(c, status, err)
+	}
+
+	return c.JSON(subscription)
+}
+
+// subscriptionErrorStatus maps subscription lifecycle errors to HTTP statuses
+func subscriptionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, subscriptions.ErrEmptyUpdate), errors.Is(err, subscriptions.ErrInvalidStatus),
+		errors.Is(err, subscriptions.ErrEmptyChange), errors.Is(err, subscriptions.ErrInvalidQuantity),
+		errors.Is(err, subscriptions.ErrProrationDate):
+		return fiber.StatusUnprocessableEntity
+	case errors.Is(err, stripe.ErrNotReactivatable), errors.Is(err, subscriptions.ErrCurrencyMismatch):
+		return fiber.StatusConflict
+	default:
+		return fiber.StatusBadRequest
+	}
+}
+
+// createSubscription handles subscribing a customer to a plan, charged to
+// the customer's default payment method
+func (a *App) createSubscription(c *fiber.Ctx) error {
+	var request stripe.SubscriptionRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	subscription, err := a.subscriptions.Create(c.Context(), &request)
+	if err != nil {
+		return a.errorResponse(c, subscriptionErrorStatus(err), err)
+	}
+
+	// A first invoice declined on the default payment method is retried with
+	// the customer's backups
+	subscription, err = a.paymentMethods.RecoverSubscription(c.Context(), requestTenant(c), subscription)
+	if err != nil {
+		requestid.Logf(c.Context(), "Failed to recover subscription %s with backup payment methods: %v", subscription.ID, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(subscription)
+}
+
+// listSubscriptions handles listing subscriptions, newest first, optionally
+// of one customer (?customer_id=) or in one status (?status=)
+func (a *App) listSubscriptions(c *fiber.Ctx) error {
+	status := c.Query("status")
+	if !subscriptions.ValidStatus(status) {
+		return a.errorResponse(c, fiber.StatusUnprocessableEntity, subscriptions.ErrInvalidStatus)
+	}
+
+	page, err := pageRequest(c)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	list, err := a.subscriptionService.ListSubscriptions(c.Context(), c.Query("customer_id"), status, page)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+	list, hasMore, nextCursor := trimPage(list, page, func(subscription *stripe.Subscription) string {
+		return subscription.ID
+	})
+
+	return c.JSON(pageResponse(list, hasMore, nextCursor))
+}
+
+// updateSubscription handles moving a subscription to another plan or
+// replacing its metadata
+func (a *App) updateSubscription(c *fiber.Ctx) error {
+	subscriptionID := c.Params("id")
+	if subscriptionID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Subscription ID is required", i18n.KeyMissingParameter)
+	}
+
+	var request subscriptions.UpdateRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	subscription, err := a.subscriptions.Update(c.Context(), subscriptionID, &request)
+	if err != nil {
+		return a.errorResponse(c, subscriptionErrorStatus(err), err)
+	}
+
+	return c.JSON(subscription)
+}
+
+// previewSubscriptionChange handles computing the prorated amount of moving
+// a subscription to another plan or quantity
+func (a *App) previewSubscriptionChange(c *fiber.Ctx) error {
+	subscriptionID := c.Params("id")
+	if subscriptionID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Subscription ID is required", i18n.KeyMissingParameter)
+	}
+
+	var request subscriptions.PreviewRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	preview, err := a.subscriptions.Preview(c.Context(), subscriptionID, &request)
+	if err != nil {
+		return a.errorResponse(c, subscriptionErrorStatus(err), err)
+	}
+
+	return c.JSON(preview)
+}
+
+// cancelSubscription handles cancelling a subscription immediately or at
+// the end of its period
+func (a *App) cancelSubscription(c *fiber.Ctx) error {
+	subscriptionID := c.Params("id")
+	if subscriptionID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Subscription ID is required", i18n.KeyMissingParameter)
+	}
+
+	var request subscriptions.CancelRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+		}
+	}
+
+	subscription, err := a.subscriptions.Cancel(c.Context(), subscriptionID, &request)
+	if err != nil {
+		return a.errorResponse(c, subscriptionErrorStatus(err), err)
+	}
+
+	return c.JSON(subscription)
+}
+
+// reactivateSubscription handles keeping a subscription set to cancel at
+// period end running
+func (a *App) reactivateSubscription(c *fiber.Ctx) error {
+	subscriptionID := c.Params("id")
+	if subscriptionID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Subscription ID is required", i18n.KeyMissingParameter)
+	}
+
+	subscription, err := a.subscriptions.Reactivate(c.Context(), subscriptionID)
+	if err != nil {
+		return a.errorResponse(c, subscriptionErrorStatus(err), err)
+	}
+
+	return c.JSON(subscription)
+}
+
+// createPlan handles creating a recurring plan
+func (a *App) createPlan(c *fiber.Ctx) error {
+	var request stripe.PlanRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	plan, err := a.subscriptionService.CreatePlan(c.Context(), &request)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(plan)
+}
+
+// getPlan handles plan retrieval
+func (a *App) getPlan(c *fiber.Ctx) error {
+	planID := c.Params("id")
+	if planID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Plan ID is required", i18n.KeyMissingParameter)
+	}
+
+	plan, err := a.subscriptionService.GetPlan(c.Context(), planID)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusNotFound, err)
+	}
+
+	return c.JSON(plan)
+}
+
+// listPlans handles listing plans, newest first, optionally of one product
+// (?product_id=) or only active ones (?active=true)
+func (a *App) listPlans(c *fiber.Ctx) error {
+	page, err := pageRequest(c)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	plans, err := a.subscriptionService.ListPlans(c.Context(), c.Query("product_id"), c.QueryBool("active"), page)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+	plans, hasMore, nextCursor := trimPage(plans, page, func(plan *stripe.SubscriptionPlan) string {
+		return plan.ID
+	})
+
+	return c.JSON(pageResponse(plans, hasMore, nextCursor))
+}
+
+// updatePlan handles renaming, archiving or restoring a plan
+func (a *App) updatePlan(c *fiber.Ctx) error {
+	planID := c.Params("id")
+	if planID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Plan ID is required", i18n.KeyMissingParameter)
+	}
+
+	var request stripe.PlanUpdate
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	plan, err := a.subscriptionService.UpdatePlan(c.Context(), planID, &request)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	return c.JSON(plan)
+}
+
+// archivePlan handles archiving a plan so no new subscriptions can use it
+func (a *App) archivePlan(c *fiber.Ctx) error {
+	planID := c.Params("id")
+	if planID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Plan ID is required", i18n.KeyMissingParameter)
+	}
+
+	plan, err := a.subscriptionService.ArchivePlan(c.Context(), planID)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	return c.JSON(plan)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+
+	"apis/payments/services/i18n"
+	"apis/payments/services/tenancy"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// resolveTenant binds each API request to its tenant, taken from the API key
+// it was authenticated with or the X-Tenant-ID header. Everything the
+// request reads, stores or sends to a provider is then scoped to the tenant.
+func (a *App) resolveTenant(c *fiber.Ctx) error {
+	tenant, err := a.tenancy.Resolve(c.Context(), requestTenant(c))
+	if err != nil {
+		return a.errorResponse(c, tenantErrorStatus(err), err)
+	}
+
+	tenancy.Bind(c.Context(), tenant.ID)
+	return c.Next()
+}
+
+// tenantErrorStatus maps tenancy errors to HTTP status codes
+func tenantErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, tenancy.ErrUnknownTenant), errors.Is(err, tenancy.ErrTenantSuspended):
+		return fiber.StatusForbidden
+	case errors.Is(err, tenancy.ErrInvalidTenant):
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// updateTenantConfigRequest replaces a tenant's configuration
+type updateTenantConfigRequest struct {
+	Name            string `json:"name"`
+	Status          string `json:"status"`
+	DefaultProvider string `json:"default_provider"`
+}
+
+// listTenantConfigs handles listing every tenant configuration
+func (a *App) listTenantConfigs(c *fiber.Ctx) error {
+	tenants, err := a.tenancy.List(c.Context())
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(fiber.Map{"data": tenants})
+}
+
+// getTenantConfig handles retrieving a tenant's configuration
+func (a *App) getTenantConfig(c *fiber.Ctx) error {
+	tenant, err := a.tenancy.Get(c.Context(), c.Params("tenantId"))
+	if errors.Is(err, tenancy.ErrUnknownTenant) {
+		return a.errorMessage(c, fiber.StatusNotFound, "Tenant not found", i18n.KeyNotFound)
+	}
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(tenant)
+}
+
+// updateTenantConfig handles creating or replacing a tenant's configuration
+func (a *App) updateTenantConfig(c *fiber.Ctx) error {
+	var request updateTenantConfigRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+
+	tenant, err := a.tenancy.Save(c.Context(), &tenancy.Tenant{
+		ID:              c.Params("tenantId"),
+		Name:            request.Name,
+		Status:          request.Status,
+		DefaultProvider: request.DefaultProvider,
+	})
+	if err != nil {
+		return a.errorResponse(c, tenantErrorStatus(err), err)
+	}
+
+	return c.JSON(tenant)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	"apis/payments/services/i18n"
+	"apis/payments/services/stripe"
+	"apis/payments/services/usage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// usageErrorStatus maps usage errors to HTTP statuses
+func usageErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usage.ErrInvalidUsage):
+		return fiber.StatusUnprocessableEntity
+	case errors.Is(err, stripe.ErrNotMetered):
+		return fiber.StatusConflict
+	default:
+		return fiber.StatusBadRequest
+	}
+}
+
+// reportUsage handles reporting usage of a subscription's metered price. An
+// Idempotency-Key header makes retried reports count once. The response is
+// 202 when the record is kept but could not be pushed to the provider yet.
+func (a *App) reportUsage(c *fiber.Ctx) error {
+	subscriptionID := c.Params("id")
+	if subscriptionID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Subscription ID is required", i18n.KeyMissingParameter)
+	}
+
+	var request usage.ReportRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+	request.IdempotencyKey = c.Get("Idempotency-Key")
+
+	record, err := a.usage.Report(c.Context(), subscriptionID, &request)
+	if err != nil {
+		return a.errorResponse(c, usageErrorStatus(err), err)
+	}
+
+	if record.ReportedAt == nil {
+		return c.Status(fiber.StatusAccepted).JSON(record)
+	}
+	return c.Status(fiber.StatusCreated).JSON(record)
+}
+
+// getUsage handles querying a subscription's consumption. It defaults to the
+// current billing period and the price's aggregation; from and to (RFC 3339)
+// and aggregation (sum, max or last) override them.
+func (a *App) getUsage(c *fiber.Ctx) error {
+	subscriptionID := c.Params("id")
+	if subscriptionID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Subscription ID is required", i18n.KeyMissingParameter)
+	}
+
+	from, err := optionalQueryTime(c, "from")
+	if err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "from must be an RFC 3339 timestamp", i18n.KeyInvalidRequest)
+	}
+	to, err := optionalQueryTime(c, "to")
+	if err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "to must be an RFC 3339 timestamp", i18n.KeyInvalidRequest)
+	}
+
+	window := usage.Window{From: from, To: to, Aggregation: c.Query("aggregation")}
+	consumption, err := a.usage.Consumption(c.Context(), subscriptionID, window)
+	if err != nil {
+		return a.errorResponse(c, usageErrorStatus(err), err)
+	}
+
+	return c.JSON(consumption)
+}
+
+// optionalQueryTime parses an RFC 3339 query parameter, returning nil when it is not set
+func optionalQueryTime(c *fiber.Ctx, name string) (*time.Time, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
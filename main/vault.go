@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"apis/payments/services/i18n"
+	"apis/payments/services/stripe"
+	"apis/payments/services/vault"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// vaultProvider is the provider payment methods are vaulted with today.
+// Provider tokens passed without a vault token are assumed to belong to it.
+const vaultProvider = "stripe"
+
+// errUnroutedProvider is returned when a vault token points at a provider
+// this deployment does not route payments to
+var errUnroutedProvider = errors.New("payment method is held by a provider that is not configured")
+
+// vaultedPaymentMethod is a payment method with its provider-agnostic token
+type vaultedPaymentMethod struct {
+	*stripe.PaymentMethod
+	Token string `json:"token"`
+}
+
+// vaultPaymentMethod issues (or reuses) the vault token for a payment method
+func (a *App) vaultPaymentMethod(ctx context.Context, paymentMethod *stripe.PaymentMethod) (*vaultedPaymentMethod, error) {
+	token := &vault.Token{
+		Provider:      vaultProvider,
+		ProviderToken: paymentMethod.ID,
+		CustomerID:    paymentMethod.Customer,
+		Type:          paymentMethod.Type,
+	}
+	if paymentMethod.Card != nil {
+		token.Fingerprint = paymentMethod.Card.Fingerprint
+	} else if paymentMethod.BankAccount != nil {
+		token.Fingerprint = paymentMethod.BankAccount.Fingerprint
+	}
+
+	token, err := a.vault.Issue(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to vault payment method: %w", err)
+	}
+
+	return &vaultedPaymentMethod{PaymentMethod: paymentMethod, Token: token.ID}, nil
+}
+
+// resolvePaymentMethod maps a vault token to the provider payment method ID.
+// Provider IDs are passed through unchanged.
+func (a *App) resolvePaymentMethod(ctx context.Context, id string) (string, error) {
+	provider, providerToken, err := a.vault.Resolve(ctx, id, vaultProvider)
+	if err != nil {
+		return "", err
+	}
+	if provider != vaultProvider {
+		return "", errUnroutedProvider
+	}
+
+	return providerToken, nil
+}
+
+// vaultErrorStatus maps vault errors to HTTP status codes
+func vaultErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, vault.ErrTokenNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, errUnroutedProvider):
+		return fiber.StatusUnprocessableEntity
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// getVaultToken returns the provider mapping behind a vault token
+func (a *App) getVaultToken(c *fiber.Ctx) error {
+	token, err := a.vault.Get(c.Context(), c.Params("id"))
+	if err != nil {
+		return a.errorResponse(c, vaultErrorStatus(err), err)
+	}
+
+	return c.JSON(token)
+}
+
+// listVaultTokens lists the vault tokens issued for a customer
+func (a *App) listVaultTokens(c *fiber.Ctx) error {
+	customerID := c.Query("customer_id")
+	if customerID == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "customer_id is required", i18n.KeyMissingParameter)
+	}
+
+	tokens, err := a.vault.ListForCustomer(c.Context(), customerID)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(tokens)
+}
+
+// remapVaultTokenRequest points a vault token at another provider's payment method
+type remapVaultTokenRequest struct {
+	Provider      string `json:"provider"`
+	ProviderToken string `json:"provider_token"`
+}
+
+// remapVaultToken moves a vault token to another provider after a card migration
+func (a *App) remapVaultToken(c *fiber.Ctx) error {
+	var request remapVaultTokenRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+	if request.Provider == "" || request.ProviderToken == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "provider and provider_token are required", i18n.KeyMissingParameter)
+	}
+
+	token, err := a.vault.Remap(c.Context(), c.Params("id"), request.Provider, request.ProviderToken)
+	if err != nil {
+		return a.errorResponse(c, vaultErrorStatus(err), err)
+	}
+
+	return c.JSON(token)
+}
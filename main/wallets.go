@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+
+	"apis/payments/services/i18n"
+	"apis/payments/services/stripe"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// walletChargeRequest is a charge paid with a wallet payment token instead
+// of a payment method
+type walletChargeRequest struct {
+	stripe.ChargeRequest
+	stripe.WalletToken
+	CustomFields map[string]string `json:"custom_fields,omitempty"`
+}
+
+// registerWalletDomainRequest names the domain to register
+type registerWalletDomainRequest struct {
+	DomainName string `json:"domain_name"`
+}
+
+// registerWalletDomain registers a domain for Apple Pay and Google Pay
+func (a *App) registerWalletDomain(c *fiber.Ctx) error {
+	var request registerWalletDomainRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+	if request.DomainName == "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Domain name is required", i18n.KeyMissingParameter)
+	}
+
+	domain, err := a.wallets.RegisterDomain(c.Context(), request.DomainName)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(domain)
+}
+
+// listWalletDomains lists the domains registered for wallets
+func (a *App) listWalletDomains(c *fiber.Ctx) error {
+	page, err := pageRequest(c)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	domains, err := a.wallets.ListDomains(c.Context(), page)
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	domains, hasMore, nextCursor := trimPage(domains, page, func(domain *stripe.WalletDomain) string { return domain.ID })
+	return c.JSON(pageResponse(domains, hasMore, nextCursor))
+}
+
+// validateWalletDomain verifies a registered domain with Apple Pay again
+func (a *App) validateWalletDomain(c *fiber.Ctx) error {
+	domain, err := a.wallets.ValidateDomain(c.Context(), c.Params("id"))
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusBadRequest, err)
+	}
+
+	return c.JSON(domain)
+}
+
+// createWalletCharge charges an Apple Pay or Google Pay payment token. The
+// token is decoded into a card payment method and charged like any other.
+func (a *App) createWalletCharge(c *fiber.Ctx) error {
+	var request walletChargeRequest
+	if err := c.BodyParser(&request); err != nil {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Invalid request body", i18n.KeyInvalidRequest)
+	}
+	if request.PaymentMethod != "" || request.Source != "" {
+		return a.errorMessage(c, fiber.StatusBadRequest, "Wallet charges are paid with payment_token only", i18n.KeyInvalidRequest)
+	}
+
+	paymentMethodID, err := a.wallets.PaymentMethodForToken(c.Context(), &request.WalletToken)
+	if err != nil {
+		return a.errorResponse(c, walletErrorStatus(err), err)
+	}
+	request.PaymentMethod = paymentMethodID
+
+	return a.submitCharge(c.Context(), c, &request.ChargeRequest, request.CustomFields)
+}
+
+// walletErrorStatus maps wallet token errors to HTTP statuses
+func walletErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, stripe.ErrUnsupportedWallet), errors.Is(err, stripe.ErrInvalidWalletToken):
+		return fiber.StatusUnprocessableEntity
+	default:
+		return fiber.StatusBadRequest
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"apis/payments/services"
+	"apis/payments/services/backpressure"
+	"apis/payments/services/deadletter"
+	"apis/payments/services/i18n"
+	"apis/payments/services/relay"
+	"apis/payments/services/requestid"
+	"apis/payments/services/stripe"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// handleStripeWebhook verifies and dispatches incoming Stripe events
+func (a *App) handleStripeWebhook(c *fiber.Ctx) error {
+	event, secret, err := a.webhookService.VerifyEvent(c.Body(), c.Get("Stripe-Signature"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":      err.Error(),
+			"request_id": requestID(c),
+		})
+	}
+
+	if _, err := a.webhookService.Process(c.Context(), event, stripe.EventSourceWebhook); err != nil {
+		// A non-2xx response makes Stripe retry the delivery; the event is
+		// also kept in the dead-letter queue in case Stripe gives up first
+		requestid.Logf(c.Context(), "Failed to process webhook %s: %v", event.ID, err)
+		if _, recordErr := a.deadLetters.Record(c.Context(), deadletter.SourceStripeWebhook, event.ID, c.Body(), map[string]string{"type": string(event.Type)}, err); recordErr != nil {
+			requestid.Logf(c.Context(), "Failed to dead-letter webhook %s: %v", event.ID, recordErr)
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":      err.Error(),
+			"request_id": requestID(c),
+		})
+	}
+
+	// Deliveries signed with a new secret confirm its rotation
+	if err := a.webhookSecrets.Observe(c.Context(), secret); err != nil {
+		requestid.Logf(c.Context(), "Failed to record webhook secret rotation delivery for %s: %v", event.ID, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"received": true,
+	})
+}
+
+// newPaddleWebhooks creates the gateway Paddle notifications are verified
+// and handled with when PADDLE_WEBHOOK_SECRET is set. Notifications are
+// for the platform's Paddle account, like Stripe's.
+func newPaddleWebhooks(relayService *relay.Service) *services.PaddleGateway {
+	if os.Getenv("PADDLE_WEBHOOK_SECRET") == "" {
+		return nil
+	}
+
+	gateway, err := services.CreateProviderGatewayFromEnv("paddle")
+	if err != nil {
+		log.Fatalf("Invalid Paddle webhook configuration: %v", err)
+	}
+	paddleWebhooks := gateway.(*services.PaddleGateway)
+	relayService.RegisterPaddleHandlers(paddleWebhooks)
+
+	return paddleWebhooks
+}
+
+// handlePaddleWebhook verifies and dispatches incoming Paddle notifications.
+// Paddle retries failed deliveries on its own schedule, so failures are not
+// dead-lettered.
+func (a *App) handlePaddleWebhook(c *fiber.Ctx) error {
+	if a.paddleWebhooks == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error":      "Paddle webhooks are not configured",
+			"request_id": requestID(c),
+		})
+	}
+
+	event, err := a.paddleWebhooks.HandleWebhook(c.Context(), c.Body(), c.Get("Paddle-Signature"))
+	if err != nil {
+		// Only notifications that failed verification come back without an ID
+		status := fiber.StatusBadRequest
+		if event.EventID != "" {
+			requestid.Logf(c.Context(), "Failed to process Paddle webhook %s: %v", event.EventID, err)
+			status = fiber.StatusInternalServerError
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"error":      err.Error(),
+			"request_id": requestID(c),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"received": true,
+	})
+}
+
+// newPayPalWebhooks creates the gateway PayPal events are verified and
+// handled with when PAYPAL_WEBHOOK_ID is set
+func newPayPalWebhooks(relayService *relay.Service) *services.PayPalGateway {
+	if os.Getenv("PAYPAL_WEBHOOK_ID") == "" {
+		return nil
+	}
+
+	gateway, err := services.CreateProviderGatewayFromEnv("paypal")
+	if err != nil {
+		log.Fatalf("Invalid PayPal webhook configuration: %v", err)
+	}
+	paypalWebhooks := gateway.(*services.PayPalGateway)
+	relayService.RegisterPayPalHandlers(paypalWebhooks)
+
+	return paypalWebhooks
+}
+
+// handlePayPalWebhook verifies and dispatches incoming PayPal events.
+// Verification is a call to PayPal, so a delivery PayPal can't be reached
+// to verify is rejected and redelivered later.
+func (a *App) handlePayPalWebhook(c *fiber.Ctx) error {
+	if a.paypalWebhooks == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error":      "PayPal webhooks are not configured",
+			"request_id": requestID(c),
+		})
+	}
+
+	header := http.Header{}
+	for name, values := range c.GetReqHeaders() {
+		for _, value := range values {
+			header.Add(name, value)
+		}
+	}
+
+	event, err := a.paypalWebhooks.HandleWebhook(c.Context(), c.Body(), services.PayPalTransmissionFromHeaders(header))
+	if err != nil {
+		// Only deliveries that failed verification come back without an ID
+		status := fiber.StatusBadRequest
+		if event.ID != "" {
+			requestid.Logf(c.Context(), "Failed to process PayPal webhook %s: %v", event.ID, err)
+			status = fiber.StatusInternalServerError
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"error":      err.Error(),
+			"request_id": requestID(c),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"received": true,
+	})
+}
+
+// webhookBackpressure sheds deliveries while webhook processing is saturated.
+// Providers redeliver rejected events, so answering at once with a
+// Retry-After spreads redelivery out instead of letting requests time out.
+func (a *App) webhookBackpressure(c *fiber.Ctx) error {
+	release, rejection := a.backpressure.Acquire()
+	if rejection != nil {
+		status := fiber.StatusTooManyRequests
+		if rejection.Reason == backpressure.ReasonDatabaseSlow {
+			status = fiber.StatusServiceUnavailable
+		}
+
+		seconds := int(math.Ceil(rejection.RetryAfter.Seconds()))
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+		return c.Status(status).JSON(fiber.Map{
+			"error":       "webhook processing is saturated",
+			"request_id":  requestID(c),
+			"reason":      rejection.Reason,
+			"retry_after": seconds,
+		})
+	}
+	defer release()
+
+	return c.Next()
+}
+
+// getWebhookBackpressure reports the signals webhook shedding is based on
+func (a *App) getWebhookBackpressure(c *fiber.Ctx) error {
+	return c.JSON(a.backpressure.Status())
+}
+
+// catchUpWebhooks handles on-demand catch-up of missed webhook events.
+// An optional since query parameter (RFC 3339) overrides the resume point.
+func (a *App) catchUpWebhooks(c *fiber.Ctx) error {
+	var since time.Time
+	if raw := c.Query("since"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return a.errorMessage(c, fiber.StatusBadRequest, "since must be an RFC 3339 timestamp", i18n.KeyInvalidRequest)
+		}
+		since = parsed
+	}
+
+	result, err := a.webhookService.CatchUp(c.Context(), since)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":      err.Error(),
+			"request_id": requestID(c),
+			"result":     result,
+		})
+	}
+
+	return c.JSON(result)
+}
+
+// catchUpAfterDowntime replays events created while the service was down
+func (a *App) catchUpAfterDowntime(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	if _, err := a.webhookService.CatchUp(ctx, time.Time{}); err != nil {
+		log.Printf("Webhook catch-up after startup failed: %v", err)
+	}
+}
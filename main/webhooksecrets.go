@@ -0,0 +1,82 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+
+	"apis/payments/services/i18n"
+	"apis/payments/services/tenantcredentials"
+	"apis/payments/services/webhooksecrets"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// webhookSecretErrorStatus maps webhook secret rotation errors to HTTP status codes
+func webhookSecretErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return fiber.StatusNotFound
+	case errors.Is(err, webhooksecrets.ErrRotationInProgress), errors.Is(err, webhooksecrets.ErrNotPending):
+		return fiber.StatusConflict
+	case errors.Is(err, webhooksecrets.ErrNotConfirmed):
+		return fiber.StatusUnprocessableEntity
+	case errors.Is(err, webhooksecrets.ErrEndpointNotConfigured), errors.Is(err, tenantcredentials.ErrEncryptionNotConfigured):
+		return fiber.StatusServiceUnavailable
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// rotateWebhookSecret handles starting a webhook secret rotation
+func (a *App) rotateWebhookSecret(c *fiber.Ctx) error {
+	rotation, err := a.webhookSecrets.Rotate(c.Context())
+	if err != nil {
+		return a.errorResponse(c, webhookSecretErrorStatus(err), err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(rotation)
+}
+
+// listWebhookSecretRotations handles listing recent webhook secret rotations
+func (a *App) listWebhookSecretRotations(c *fiber.Ctx) error {
+	rotations, err := a.webhookSecrets.List(c.Context(), c.QueryInt("limit", 100))
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(fiber.Map{"data": rotations})
+}
+
+// getWebhookSecretRotation handles retrieving a webhook secret rotation
+func (a *App) getWebhookSecretRotation(c *fiber.Ctx) error {
+	rotation, err := a.webhookSecrets.Get(c.Context(), c.Params("id"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return a.errorMessage(c, fiber.StatusNotFound, "Webhook secret rotation not found", i18n.KeyNotFound)
+	}
+	if err != nil {
+		return a.errorResponse(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(rotation)
+}
+
+// completeWebhookSecretRotation handles retiring the old secret before the
+// grace period ends
+func (a *App) completeWebhookSecretRotation(c *fiber.Ctx) error {
+	rotation, err := a.webhookSecrets.Complete(c.Context(), c.Params("id"))
+	if err != nil {
+		return a.errorResponse(c, webhookSecretErrorStatus(err), err)
+	}
+
+	return c.JSON(rotation)
+}
+
+// cancelWebhookSecretRotation handles abandoning a rotation and keeping the old secret
+func (a *App) cancelWebhookSecretRotation(c *fiber.Ctx) error {
+	rotation, err := a.webhookSecrets.Cancel(c.Context(), c.Params("id"))
+	if err != nil {
+		return a.errorResponse(c, webhookSecretErrorStatus(err), err)
+	}
+
+	return c.JSON(rotation)
+}
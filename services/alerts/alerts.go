@@ -0,0 +1,89 @@
+// Package alerts delivers operational alerts to the service log and to
+// notification webhooks. Alerters are typed by the alert they deliver, so
+// each service keeps its own alert payload.
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Alerter delivers alerts of one kind
+type Alerter[T any] interface {
+	Alert(ctx context.Context, alert T) error
+}
+
+// Messager describes an alert as the lines written to the service log
+type Messager interface {
+	Messages() []string
+}
+
+// LogAlerter writes alerts to the service log
+type LogAlerter[T Messager] struct{}
+
+// Alert logs each of the alert's messages
+func (LogAlerter[T]) Alert(ctx context.Context, alert T) error {
+	for _, message := range alert.Messages() {
+		log.Printf("ALERT: %s", message)
+	}
+	return nil
+}
+
+// WebhookAlerter posts alerts as JSON to a notification endpoint
+type WebhookAlerter[T any] struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookAlerter creates an alerter posting to the given URL
+func NewWebhookAlerter[T any](url string) *WebhookAlerter[T] {
+	return &WebhookAlerter[T]{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Alert posts the alert to the configured URL
+func (a *WebhookAlerter[T]) Alert(ctx context.Context, alert T) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build alert request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send alert: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("alert endpoint returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// MultiAlerter fans an alert out to several alerters
+type MultiAlerter[T any] []Alerter[T]
+
+// Alert delivers the alert to every alerter, returning the first error
+func (m MultiAlerter[T]) Alert(ctx context.Context, alert T) error {
+	var firstErr error
+	for _, alerter := range m {
+		if err := alerter.Alert(ctx, alert); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
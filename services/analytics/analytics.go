@@ -0,0 +1,190 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// MaxBuckets is the most buckets a report spans
+const MaxBuckets = 366
+
+// Errors returned for report queries
+var (
+	ErrInvalidRange       = errors.New("from must be before to")
+	ErrRangeTooLarge      = errors.New("range spans more than 366 buckets; use a coarser granularity")
+	ErrInvalidGranularity = errors.New("granularity must be day, week or month")
+)
+
+// Granularity is the length of the buckets a report is broken into. Buckets
+// start at midnight UTC, weeks on Monday.
+type Granularity string
+
+// Granularities reports can be broken into
+const (
+	GranularityDay   Granularity = "day"
+	GranularityWeek  Granularity = "week"
+	GranularityMonth Granularity = "month"
+)
+
+// Valid reports whether g is a known granularity
+func (g Granularity) Valid() bool {
+	switch g {
+	case GranularityDay, GranularityWeek, GranularityMonth:
+		return true
+	}
+	return false
+}
+
+// Truncate returns the start of the bucket holding t
+func (g Granularity) Truncate(t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch g {
+	case GranularityWeek:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case GranularityMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day
+	}
+}
+
+// Next returns the start of the bucket after the one starting at start
+func (g Granularity) Next(start time.Time) time.Time {
+	switch g {
+	case GranularityWeek:
+		return start.AddDate(0, 0, 7)
+	case GranularityMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// Query selects the events a report is computed over: those of a tenant
+// from From up to To, optionally in one currency
+type Query struct {
+	TenantID    string
+	From        time.Time
+	To          time.Time
+	Granularity Granularity
+	Currency    string // All currencies when empty
+}
+
+// ChargeBucket counts the charges created in a bucket. Amounts are in the
+// currency's minor units.
+type ChargeBucket struct {
+	Start       time.Time `json:"start"`
+	Currency    string    `json:"-"`
+	Charges     int64     `json:"charges"`
+	Succeeded   int64     `json:"succeeded"`
+	Failed      int64     `json:"failed"`
+	SuccessRate float64   `json:"success_rate"`
+	Volume      int64     `json:"volume"` // Succeeded charges
+}
+
+// RefundBucket totals the refunds that succeeded in a bucket
+type RefundBucket struct {
+	Start    time.Time
+	Currency string
+	Refunds  int64
+	Amount   int64
+}
+
+// RevenueBucket is the revenue of a bucket: succeeded charges less refunds
+type RevenueBucket struct {
+	Start    time.Time `json:"start"`
+	Currency string    `json:"-"`
+	Gross    int64     `json:"gross"`
+	Refunded int64     `json:"refunded"`
+	Net      int64     `json:"net"`
+	Charges  int64     `json:"charges"` // Succeeded charges
+	Refunds  int64     `json:"refunds"`
+}
+
+// MRRBucket is the monthly recurring revenue at the end of a bucket and how
+// it moved during the bucket. MRR at the start plus new and expansion, less
+// contraction and churned, is MRR at the end.
+type MRRBucket struct {
+	Start               time.Time `json:"start"`
+	Currency            string    `json:"-"`
+	MRR                 int64     `json:"mrr"`
+	NewMRR              int64     `json:"new_mrr"`
+	ExpansionMRR        int64     `json:"expansion_mrr"`
+	ContractionMRR      int64     `json:"contraction_mrr"`
+	ChurnedMRR          int64     `json:"churned_mrr"`
+	ActiveSubscriptions int64     `json:"active_subscriptions"`
+}
+
+// ChurnBucket is the share of subscriptions, and of their MRR, that were
+// active at the start of a bucket and stopped paying during it
+type ChurnBucket struct {
+	Start            time.Time `json:"start"`
+	Currency         string    `json:"-"`
+	ActiveAtStart    int64     `json:"active_at_start"`
+	Churned          int64     `json:"churned"`
+	ChurnRate        float64   `json:"churn_rate"`
+	MRRAtStart       int64     `json:"mrr_at_start"`
+	ChurnedMRR       int64     `json:"churned_mrr"`
+	RevenueChurnRate float64   `json:"revenue_churn_rate"`
+}
+
+// Series is a report's buckets in one currency, one per bucket of the range
+type Series[T any] struct {
+	Currency string `json:"currency"`
+	Buckets  []T    `json:"buckets"`
+}
+
+// SubscriptionChange is a subscription's status and MRR after an event
+type SubscriptionChange struct {
+	SubscriptionID string
+	Status         string
+	Currency       string
+	MRR            int64
+	At             time.Time
+}
+
+// DeclineReason counts the charges declined for a reason
+type DeclineReason struct {
+	Code  string  `json:"code"` // Provider failure code; empty when none was given
+	Count int64   `json:"count"`
+	Share float64 `json:"share"` // Share of all declines
+}
+
+// Store aggregates the logged payment events. Charge and refund buckets
+// are keyed by the start of their bucket and their currency; derived values
+// are computed by the service.
+type Store interface {
+	ChargeBuckets(ctx context.Context, query *Query) ([]*ChargeBucket, error)
+	RefundBuckets(ctx context.Context, query *Query) ([]*RefundBucket, error)
+	// SubscriptionChanges returns every change before query.To in order,
+	// since MRR depends on changes before the range
+	SubscriptionChanges(ctx context.Context, query *Query) ([]*SubscriptionChange, error)
+	DeclineReasons(ctx context.Context, query *Query) ([]*DeclineReason, error)
+}
+
+// payingStatuses are the subscription statuses that count towards MRR
+var payingStatuses = map[string]bool{
+	"active":   true,
+	"past_due": true,
+}
+
+// MonthlyAmount normalizes a recurring price to a month, e.g. 12000 a
+// year is 1000 a month
+func MonthlyAmount(amount, quantity int64, interval string, intervalCount int64) int64 {
+	total := amount * quantity
+	count := max(intervalCount, 1)
+	switch interval {
+	case "day":
+		return total * 365 / (12 * count)
+	case "week":
+		return total * 52 / (12 * count)
+	case "month":
+		return total / count
+	case "year":
+		return total / (12 * count)
+	default:
+		return 0
+	}
+}
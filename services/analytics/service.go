@@ -0,0 +1,372 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Service reports charge, revenue and subscription metrics over the logged
+// payment events, broken into buckets per currency
+type Service struct {
+	store  Store
+	tracer trace.Tracer
+}
+
+// NewService creates a new analytics service
+func NewService(store Store) *Service {
+	return &Service{
+		store:  store,
+		tracer: otel.Tracer("payments.analytics"),
+	}
+}
+
+// Charges counts the charges created in each bucket and the share that
+// succeeded
+func (s *Service) Charges(ctx context.Context, query *Query) ([]*Series[*ChargeBucket], error) {
+	ctx, span := s.tracer.Start(ctx, "Analytics.Charges")
+	defer span.End()
+
+	starts, err := bucketStarts(query)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.store.ChargeBuckets(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate charges: %w", err)
+	}
+
+	charges := make(map[bucketKey]*ChargeBucket)
+	currencies := newCurrencySet(query)
+	for _, row := range rows {
+		charges[bucketKey{row.Currency, row.Start.Unix()}] = row
+		currencies.add(row.Currency)
+	}
+
+	return fill(starts, currencies.list(), func(currency string, start time.Time) *ChargeBucket {
+		bucket := charges[bucketKey{currency, start.Unix()}]
+		if bucket == nil {
+			bucket = &ChargeBucket{Start: start, Currency: currency}
+		}
+		if bucket.Charges > 0 {
+			bucket.SuccessRate = float64(bucket.Succeeded) / float64(bucket.Charges)
+		}
+		return bucket
+	}), nil
+}
+
+// Revenue totals the succeeded charges created in each bucket and the
+// refunds that succeeded in it
+func (s *Service) Revenue(ctx context.Context, query *Query) ([]*Series[*RevenueBucket], error) {
+	ctx, span := s.tracer.Start(ctx, "Analytics.Revenue")
+	defer span.End()
+
+	starts, err := bucketStarts(query)
+	if err != nil {
+		return nil, err
+	}
+
+	charges, err := s.store.ChargeBuckets(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate charges: %w", err)
+	}
+	refunds, err := s.store.RefundBuckets(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate refunds: %w", err)
+	}
+
+	revenue := make(map[bucketKey]*RevenueBucket)
+	currencies := newCurrencySet(query)
+	bucket := func(currency string, start time.Time) *RevenueBucket {
+		key := bucketKey{currency, start.Unix()}
+		if revenue[key] == nil {
+			revenue[key] = &RevenueBucket{Start: start, Currency: currency}
+			currencies.add(currency)
+		}
+		return revenue[key]
+	}
+	for _, row := range charges {
+		b := bucket(row.Currency, row.Start)
+		b.Gross += row.Volume
+		b.Charges += row.Succeeded
+	}
+	for _, row := range refunds {
+		b := bucket(row.Currency, row.Start)
+		b.Refunded += row.Amount
+		b.Refunds += row.Refunds
+	}
+
+	return fill(starts, currencies.list(), func(currency string, start time.Time) *RevenueBucket {
+		b := bucket(currency, start)
+		b.Net = b.Gross - b.Refunded
+		return b
+	}), nil
+}
+
+// MRR reports the monthly recurring revenue at the end of each bucket and
+// its movements during the bucket
+func (s *Service) MRR(ctx context.Context, query *Query) ([]*Series[*MRRBucket], error) {
+	ctx, span := s.tracer.Start(ctx, "Analytics.MRR")
+	defer span.End()
+
+	movements, err := s.subscriptionMovements(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return fill(movements.starts, movements.currencies.list(), func(currency string, start time.Time) *MRRBucket {
+		if bucket := movements.mrr[bucketKey{currency, start.Unix()}]; bucket != nil {
+			return bucket
+		}
+		return &MRRBucket{Start: start, Currency: currency}
+	}), nil
+}
+
+// Churn reports the share of paying subscriptions, and of their MRR, lost
+// in each bucket
+func (s *Service) Churn(ctx context.Context, query *Query) ([]*Series[*ChurnBucket], error) {
+	ctx, span := s.tracer.Start(ctx, "Analytics.Churn")
+	defer span.End()
+
+	movements, err := s.subscriptionMovements(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return fill(movements.starts, movements.currencies.list(), func(currency string, start time.Time) *ChurnBucket {
+		bucket := movements.churn[bucketKey{currency, start.Unix()}]
+		if bucket == nil {
+			return &ChurnBucket{Start: start, Currency: currency}
+		}
+		if bucket.ActiveAtStart > 0 {
+			bucket.ChurnRate = float64(bucket.Churned) / float64(bucket.ActiveAtStart)
+		}
+		if bucket.MRRAtStart > 0 {
+			bucket.RevenueChurnRate = float64(bucket.ChurnedMRR) / float64(bucket.MRRAtStart)
+		}
+		return bucket
+	}), nil
+}
+
+// DeclineReasons counts the charges declined in the range by failure code,
+// most frequent first
+func (s *Service) DeclineReasons(ctx context.Context, query *Query) ([]*DeclineReason, error) {
+	ctx, span := s.tracer.Start(ctx, "Analytics.DeclineReasons")
+	defer span.End()
+
+	if !query.From.Before(query.To) {
+		return nil, ErrInvalidRange
+	}
+
+	reasons, err := s.store.DeclineReasons(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate decline reasons: %w", err)
+	}
+
+	var total int64
+	for _, reason := range reasons {
+		total += reason.Count
+	}
+	for _, reason := range reasons {
+		reason.Share = float64(reason.Count) / float64(total)
+	}
+	sort.SliceStable(reasons, func(i, j int) bool {
+		if reasons[i].Count != reasons[j].Count {
+			return reasons[i].Count > reasons[j].Count
+		}
+		return reasons[i].Code < reasons[j].Code
+	})
+
+	return reasons, nil
+}
+
+// movements are the MRR and churn buckets replayed from subscription changes
+type movements struct {
+	starts     []time.Time
+	currencies *currencySet
+	mrr        map[bucketKey]*MRRBucket
+	churn      map[bucketKey]*ChurnBucket
+}
+
+// subscriptionState is where a subscription stood after its last change
+type subscriptionState struct {
+	currency string
+	mrr      int64
+	paying   bool
+}
+
+// subscriptionMovements replays every subscription change up to the end of
+// the range, tracking which subscriptions pay and their MRR. A subscription
+// pays while active or past due.
+func (s *Service) subscriptionMovements(ctx context.Context, query *Query) (*movements, error) {
+	starts, err := bucketStarts(query)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := s.store.SubscriptionChanges(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscription changes: %w", err)
+	}
+
+	result := &movements{
+		starts:     starts,
+		currencies: newCurrencySet(query),
+		mrr:        make(map[bucketKey]*MRRBucket),
+		churn:      make(map[bucketKey]*ChurnBucket),
+	}
+	mrrBucket := func(currency string, start time.Time) *MRRBucket {
+		key := bucketKey{currency, start.Unix()}
+		if result.mrr[key] == nil {
+			result.mrr[key] = &MRRBucket{Start: start, Currency: currency}
+			result.currencies.add(currency)
+		}
+		return result.mrr[key]
+	}
+	churnBucket := func(currency string, start time.Time) *ChurnBucket {
+		key := bucketKey{currency, start.Unix()}
+		if result.churn[key] == nil {
+			result.churn[key] = &ChurnBucket{Start: start, Currency: currency}
+			result.currencies.add(currency)
+		}
+		return result.churn[key]
+	}
+
+	states := make(map[string]subscriptionState)
+	apply := func(change *SubscriptionChange) (before, after subscriptionState) {
+		before = states[change.SubscriptionID]
+		after = subscriptionState{currency: change.Currency, mrr: change.MRR, paying: payingStatuses[change.Status]}
+		states[change.SubscriptionID] = after
+		return before, after
+	}
+
+	next := 0
+	for ; next < len(changes) && changes[next].At.Before(starts[0]); next++ {
+		apply(changes[next])
+	}
+
+	for _, start := range starts {
+		end := query.Granularity.Next(start)
+
+		payingAtStart := make(map[string]bool)
+		for id, state := range states {
+			if state.paying {
+				payingAtStart[id] = true
+				bucket := churnBucket(state.currency, start)
+				bucket.ActiveAtStart++
+				bucket.MRRAtStart += state.mrr
+			}
+		}
+
+		for ; next < len(changes) && changes[next].At.Before(end); next++ {
+			change := changes[next]
+			before, after := apply(change)
+			switch {
+			case !before.paying && after.paying:
+				mrrBucket(after.currency, start).NewMRR += after.mrr
+			case before.paying && !after.paying:
+				mrrBucket(before.currency, start).ChurnedMRR += before.mrr
+				if payingAtStart[change.SubscriptionID] {
+					bucket := churnBucket(before.currency, start)
+					bucket.Churned++
+					bucket.ChurnedMRR += before.mrr
+					// Resubscribing later in the bucket is new MRR, not a second churn
+					delete(payingAtStart, change.SubscriptionID)
+				}
+			case before.paying && after.paying && before.currency != after.currency:
+				mrrBucket(before.currency, start).ChurnedMRR += before.mrr
+				mrrBucket(after.currency, start).NewMRR += after.mrr
+			case before.paying && after.paying && after.mrr > before.mrr:
+				mrrBucket(after.currency, start).ExpansionMRR += after.mrr - before.mrr
+			case before.paying && after.paying && after.mrr < before.mrr:
+				mrrBucket(after.currency, start).ContractionMRR += before.mrr - after.mrr
+			}
+		}
+
+		for _, state := range states {
+			if state.paying {
+				bucket := mrrBucket(state.currency, start)
+				bucket.MRR += state.mrr
+				bucket.ActiveSubscriptions++
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// bucketStarts validates a query, aligning From to the start of its bucket,
+// and returns the start of every bucket in the range
+func bucketStarts(query *Query) ([]time.Time, error) {
+	if query.Granularity == "" {
+		query.Granularity = GranularityDay
+	}
+	if !query.Granularity.Valid() {
+		return nil, ErrInvalidGranularity
+	}
+	if !query.From.Before(query.To) {
+		return nil, ErrInvalidRange
+	}
+
+	query.From = query.Granularity.Truncate(query.From)
+	var starts []time.Time
+	for start := query.From; start.Before(query.To); start = query.Granularity.Next(start) {
+		if len(starts) == MaxBuckets {
+			return nil, ErrRangeTooLarge
+		}
+		starts = append(starts, start)
+	}
+
+	return starts, nil
+}
+
+// bucketKey identifies a bucket of a currency's series
+type bucketKey struct {
+	currency string
+	start    int64
+}
+
+// currencySet collects the currencies a report has series for: the
+// queried currency, or every currency with events
+type currencySet struct {
+	only       string
+	currencies map[string]bool
+}
+
+func newCurrencySet(query *Query) *currencySet {
+	return &currencySet{only: query.Currency, currencies: make(map[string]bool)}
+}
+
+func (c *currencySet) add(currency string) {
+	c.currencies[currency] = true
+}
+
+func (c *currencySet) list() []string {
+	if c.only != "" {
+		return []string{c.only}
+	}
+	currencies := make([]string, 0, len(c.currencies))
+	for currency := range c.currencies {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	return currencies
+}
+
+// fill builds a series per currency with a bucket for every start
+func fill[T any](starts []time.Time, currencies []string, bucket func(currency string, start time.Time) T) []*Series[T] {
+	series := make([]*Series[T], 0, len(currencies))
+	for _, currency := range currencies {
+		s := &Series[T]{Currency: currency, Buckets: make([]T, len(starts))}
+		for i, start := range starts {
+			s.Buckets[i] = bucket(currency, start)
+		}
+		series = append(series, s)
+	}
+	return series
+}
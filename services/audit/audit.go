@@ -0,0 +1,337 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Actor types, besides the type of an authenticated principal
+const (
+	ActorAPIKey       = "api_key"
+	ActorEphemeralKey = "ephemeral_key"
+	ActorAdmin        = "admin"
+	ActorAnonymous    = "anonymous"
+)
+
+// Actions every resource supports. Other actions are named after the route,
+// e.g. capture or cancel.
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// GenesisHash is the previous hash of the first entry
+var GenesisHash = strings.Repeat("0", 64)
+
+// redactedFields are response fields never written to the audit log
+var redactedFields = map[string]bool{
+	"secret":         true,
+	"client_secret":  true,
+	"token":          true,
+	"provider_token": true,
+	"payment_token":  true,
+	"access_token":   true,
+	"password":       true,
+}
+
+var (
+	// ErrEntryNotFound is returned for unknown audit entries
+	ErrEntryNotFound = errors.New("audit entry not found")
+	// ErrInvalidCreatedRange is returned when created_after is not before created_before
+	ErrInvalidCreatedRange = errors.New("created_after must be before created_before")
+)
+
+// Entry records one mutating call: who made it, what it did to which
+// resource, and the resource's state before and after. Entries are numbered
+// from 1 and chained by Hash.
+type Entry struct {
+	Sequence     int64           `json:"sequence"`
+	TenantID     string          `json:"tenant_id,omitempty"`
+	ActorType    string          `json:"actor_type"`
+	ActorID      string          `json:"actor_id,omitempty"`
+	OperatorID   string          `json:"operator_id,omitempty"`
+	Action       string          `json:"action"`
+	ResourceType string          `json:"resource_type"`
+	ResourceID   string          `json:"resource_id,omitempty"`
+	Method       string          `json:"method"`
+	Path         string          `json:"path"`
+	Status       int             `json:"status"`
+	Before       json.RawMessage `json:"before,omitempty"`
+	After        json.RawMessage `json:"after,omitempty"`
+	Changes      []Change        `json:"changes"`
+	IP           string          `json:"ip,omitempty"`
+	RequestID    string          `json:"request_id,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+	PreviousHash string          `json:"previous_hash"`
+	Hash         string          `json:"hash"`
+}
+
+// Change is a field that differs between a resource's state before and
+// after a call. Nested fields are named by their dotted path.
+type Change struct {
+	Field  string          `json:"field"`
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+// ComputeHash returns the hash chaining the entry to its predecessor. It
+// covers every field but Hash itself.
+func (e *Entry) ComputeHash() string {
+	changes := e.Changes
+	if changes == nil {
+		changes = []Change{}
+	}
+	canonical, _ := json.Marshal(struct {
+		Sequence     int64           `json:"sequence"`
+		TenantID     string          `json:"tenant_id"`
+		ActorType    string          `json:"actor_type"`
+		ActorID      string          `json:"actor_id"`
+		OperatorID   string          `json:"operator_id"`
+		Action       string          `json:"action"`
+		ResourceType string          `json:"resource_type"`
+		ResourceID   string          `json:"resource_id"`
+		Method       string          `json:"method"`
+		Path         string          `json:"path"`
+		Status       int             `json:"status"`
+		Before       json.RawMessage `json:"before"`
+		After        json.RawMessage `json:"after"`
+		Changes      []Change        `json:"changes"`
+		IP           string          `json:"ip"`
+		RequestID    string          `json:"request_id"`
+		CreatedAt    string          `json:"created_at"`
+		PreviousHash string          `json:"previous_hash"`
+	}{
+		e.Sequence, e.TenantID, e.ActorType, e.ActorID, e.OperatorID, e.Action, e.ResourceType, e.ResourceID,
+		e.Method, e.Path, e.Status, e.Before, e.After, changes, e.IP, e.RequestID,
+		e.CreatedAt.UTC().Format(time.RFC3339Nano), e.PreviousHash,
+	})
+
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:])
+}
+
+// Filter narrows an audit log listing. Empty fields match every entry.
+type Filter struct {
+	TenantID      string
+	ActorID       string
+	Action        string
+	ResourceType  string
+	ResourceID    string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	// BeforeSequence lists entries older than this sequence, for paging
+	BeforeSequence int64
+	Limit          int
+}
+
+// Verification is the result of checking the audit log's hash chain
+type Verification struct {
+	Valid   bool  `json:"valid"`
+	Checked int64 `json:"checked"`
+	// BrokenAt is the first entry whose hash or link doesn't match
+	BrokenAt *int64 `json:"broken_at,omitempty"`
+}
+
+// Store persists audit entries. AppendAuditEntry reports false without
+// writing when the entry's sequence is already taken.
+type Store interface {
+	AppendAuditEntry(ctx context.Context, entry *Entry) (bool, error)
+	GetAuditEntry(ctx context.Context, sequence int64) (*Entry, error)
+	GetLatestAuditEntry(ctx context.Context) (*Entry, error)
+	GetLatestResourceAuditEntry(ctx context.Context, resourceType, resourceID string) (*Entry, error)
+	ListAuditEntries(ctx context.Context, filter Filter) ([]*Entry, error)
+	ListAuditEntriesFrom(ctx context.Context, sequence int64, limit int) ([]*Entry, error)
+}
+
+// Recorded reports whether a call is recorded: mutations that succeeded
+func Recorded(method string, status int) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return status >= http.StatusOK && status < http.StatusMultipleChoices
+	default:
+		return false
+	}
+}
+
+// Classify names the action a call took and the resource it acted on from
+// its route, e.g. /customers/:id, and route parameters. POST to a
+// collection creates the resource in the response, PUT, PATCH and DELETE on
+// a resource update and delete it, and POST to a resource's sub-path is
+// named after the sub-path, e.g. capture, unless it creates a sub-resource
+// such as /charges/:id/refunds.
+func Classify(method, route string, params map[string]string, responseID string) (action, resourceType, resourceID string) {
+	var segments []string
+	for _, segment := range strings.Split(route, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	if len(segments) == 0 {
+		return strings.ToLower(method), "", ""
+	}
+
+	last := -1
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			last = i
+		}
+	}
+
+	// A collection, e.g. /customers or /refunds/batch
+	if last < 0 {
+		resourceType = segments[0]
+		switch {
+		case len(segments) > 1:
+			action = actionName(segments[1:])
+		case method == http.MethodDelete:
+			action = ActionDelete
+		case method == http.MethodPost:
+			action = ActionCreate
+		default:
+			action = ActionUpdate
+		}
+		return action, resourceType, responseID
+	}
+
+	if last > 0 {
+		resourceType = segments[last-1]
+	}
+	resourceID = params[strings.TrimPrefix(segments[last], ":")]
+	tail := segments[last+1:]
+
+	switch {
+	// The resource itself, e.g. /customers/:id
+	case len(tail) == 0 && method == http.MethodDelete:
+		return ActionDelete, resourceType, resourceID
+	case len(tail) == 0:
+		return ActionUpdate, resourceType, resourceID
+	// A new sub-resource, e.g. /charges/:id/refunds
+	case method == http.MethodPost && responseID != "" && responseID != resourceID:
+		return ActionCreate, tail[len(tail)-1], responseID
+	case method == http.MethodPut || method == http.MethodPatch:
+		return ActionUpdate + "_" + actionName(tail), resourceType, resourceID
+	case method == http.MethodDelete:
+		return ActionDelete + "_" + actionName(tail), resourceType, resourceID
+	default:
+		return actionName(tail), resourceType, resourceID
+	}
+}
+
+// actionName names an action after route segments, e.g. mark_paid
+func actionName(segments []string) string {
+	return strings.ReplaceAll(strings.Join(segments, "_"), "-", "_")
+}
+
+// State returns a response body as a resource state with secrets redacted,
+// and the resource's ID. Bodies that aren't JSON objects have no state.
+func State(body []byte) (json.RawMessage, string) {
+	var fields map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&fields); err != nil || fields == nil {
+		return nil, ""
+	}
+
+	redact(fields)
+	id, _ := fields["id"].(string)
+
+	state, err := json.Marshal(fields)
+	if err != nil {
+		return nil, ""
+	}
+	return state, id
+}
+
+// redact removes secret fields at any depth
+func redact(value any) {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, field := range value {
+			if redactedFields[key] {
+				delete(value, key)
+				continue
+			}
+			redact(field)
+		}
+	case []any:
+		for _, item := range value {
+			redact(item)
+		}
+	}
+}
+
+// Diff returns the fields that differ between two states, sorted by field.
+// Nested objects are compared field by field; arrays are compared whole.
+func Diff(before, after json.RawMessage) []Change {
+	beforeFields := flatten(before)
+	afterFields := flatten(after)
+
+	changes := []Change{}
+	for field, value := range afterFields {
+		previous, ok := beforeFields[field]
+		if ok && reflect.DeepEqual(previous, value) {
+			continue
+		}
+		change := Change{Field: field, After: marshalValue(value)}
+		if ok {
+			change.Before = marshalValue(previous)
+		}
+		changes = append(changes, change)
+	}
+	for field, value := range beforeFields {
+		if _, ok := afterFields[field]; !ok {
+			changes = append(changes, Change{Field: field, Before: marshalValue(value)})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+// flatten decodes a state into its leaf fields by dotted path
+func flatten(state json.RawMessage) map[string]any {
+	fields := map[string]any{}
+	if len(state) == 0 {
+		return fields
+	}
+
+	var decoded map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(state))
+	decoder.UseNumber()
+	if err := decoder.Decode(&decoded); err != nil {
+		return fields
+	}
+
+	var walk func(prefix string, value map[string]any)
+	walk = func(prefix string, value map[string]any) {
+		for key, field := range value {
+			if nested, ok := field.(map[string]any); ok && len(nested) > 0 {
+				walk(prefix+key+".", nested)
+				continue
+			}
+			fields[prefix+key] = field
+		}
+	}
+	walk("", decoded)
+	return fields
+}
+
+// marshalValue encodes a decoded field, keeping null distinguishable from a
+// missing field
+func marshalValue(value any) json.RawMessage {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	return encoded
+}
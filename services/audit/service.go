@@ -0,0 +1,206 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DefaultListLimit bounds listings that give no limit
+const DefaultListLimit = 100
+
+// verifyBatchSize is the number of entries read at a time when verifying
+const verifyBatchSize = 500
+
+// appendAttempts is how many times an entry is chained onto a newer latest
+// entry when other instances append first
+const appendAttempts = 5
+
+// ErrChainContention is returned when an entry couldn't be appended because
+// other instances kept appending first
+var ErrChainContention = errors.New("audit log is busy, entry not appended")
+
+// Call is a mutating API or admin call to record
+type Call struct {
+	Method string
+	// Route is the matched route pattern, e.g. /customers/:id
+	Route  string
+	Path   string
+	Params map[string]string
+	Status int
+	// Response is the response body, the resource's state after the call
+	Response   []byte
+	TenantID   string
+	ActorType  string
+	ActorID    string
+	OperatorID string
+	IP         string
+	RequestID  string
+}
+
+// Service records mutating calls in an append-only, hash-chained audit log
+type Service struct {
+	store  Store
+	mu     sync.Mutex
+	tracer trace.Tracer
+}
+
+// NewService creates a new audit service
+func NewService(store Store) *Service {
+	return &Service{
+		store:  store,
+		tracer: otel.Tracer("payments.audit"),
+	}
+}
+
+// Record appends a call to the audit log. The resource's state before the
+// call is its state after the last recorded call, so resources changed
+// before auditing began, or outside the API, have no known prior state.
+func (s *Service) Record(ctx context.Context, call *Call) (*Entry, error) {
+	ctx, span := s.tracer.Start(ctx, "Record")
+	defer span.End()
+
+	state, responseID := State(call.Response)
+	action, resourceType, resourceID := Classify(call.Method, call.Route, call.Params, responseID)
+
+	entry := &Entry{
+		TenantID:     call.TenantID,
+		ActorType:    call.ActorType,
+		ActorID:      call.ActorID,
+		OperatorID:   call.OperatorID,
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Method:       call.Method,
+		Path:         call.Path,
+		Status:       call.Status,
+		IP:           call.IP,
+		RequestID:    call.RequestID,
+		Changes:      []Change{},
+	}
+
+	// Responses describing something other than the resource, such as a
+	// deletion receipt, aren't its state
+	if responseID == resourceID && action != ActionDelete {
+		entry.After = state
+	}
+	if resourceID != "" && action != ActionCreate && (entry.After != nil || action == ActionDelete) {
+		previous, err := s.store.GetLatestResourceAuditEntry(ctx, resourceType, resourceID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("failed to get previous state: %w", err)
+		}
+		if previous != nil {
+			entry.Before = previous.After
+		}
+	}
+	if entry.Before != nil || entry.After != nil {
+		entry.Changes = Diff(entry.Before, entry.After)
+	}
+
+	if err := s.append(ctx, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// append chains an entry onto the latest entry and stores it. Appends are
+// serialized within the instance, and retried when another instance takes
+// the sequence first.
+func (s *Service) append(ctx context.Context, entry *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for attempt := 0; attempt < appendAttempts; attempt++ {
+		entry.Sequence = 1
+		entry.PreviousHash = GenesisHash
+		latest, err := s.store.GetLatestAuditEntry(ctx)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to get latest audit entry: %w", err)
+		}
+		if latest != nil {
+			entry.Sequence = latest.Sequence + 1
+			entry.PreviousHash = latest.Hash
+		}
+
+		// Postgres keeps microseconds
+		entry.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+		entry.Hash = entry.ComputeHash()
+
+		appended, err := s.store.AppendAuditEntry(ctx, entry)
+		if err != nil {
+			return fmt.Errorf("failed to append audit entry: %w", err)
+		}
+		if appended {
+			return nil
+		}
+	}
+
+	return ErrChainContention
+}
+
+// Get retrieves an audit entry by its sequence
+func (s *Service) Get(ctx context.Context, sequence int64) (*Entry, error) {
+	ctx, span := s.tracer.Start(ctx, "Get")
+	defer span.End()
+
+	entry, err := s.store.GetAuditEntry(ctx, sequence)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrEntryNotFound
+	}
+	return entry, err
+}
+
+// List returns audit entries matching a filter, newest first
+func (s *Service) List(ctx context.Context, filter Filter) ([]*Entry, error) {
+	ctx, span := s.tracer.Start(ctx, "List")
+	defer span.End()
+
+	if filter.CreatedAfter != nil && filter.CreatedBefore != nil && !filter.CreatedAfter.Before(*filter.CreatedBefore) {
+		return nil, ErrInvalidCreatedRange
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultListLimit
+	}
+
+	return s.store.ListAuditEntries(ctx, filter)
+}
+
+// Verify walks the audit log from its first entry, recomputing each hash
+// and checking each entry links to the one before it. The log is valid when
+// no entry was edited, removed or reordered.
+func (s *Service) Verify(ctx context.Context) (*Verification, error) {
+	ctx, span := s.tracer.Start(ctx, "Verify")
+	defer span.End()
+
+	verification := &Verification{Valid: true}
+	expected := int64(1)
+	previousHash := GenesisHash
+	for {
+		entries, err := s.store.ListAuditEntriesFrom(ctx, expected, verifyBatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list audit entries: %w", err)
+		}
+
+		for _, entry := range entries {
+			if entry.Sequence != expected || entry.PreviousHash != previousHash || entry.ComputeHash() != entry.Hash {
+				broken := expected
+				verification.Valid = false
+				verification.BrokenAt = &broken
+				return verification, nil
+			}
+			verification.Checked++
+			expected++
+			previousHash = entry.Hash
+		}
+
+		if len(entries) < verifyBatchSize {
+			return verification, nil
+		}
+	}
+}
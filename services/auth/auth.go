@@ -0,0 +1,206 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Scopes an API key or token can be granted
+const (
+	// ScopeRead allows every read
+	ScopeRead = "read"
+	// ScopeChargesWrite allows creating charges, composite charges and payment intents
+	ScopeChargesWrite = "charges:write"
+	// ScopeRefundsWrite allows creating refunds and deciding refund approvals
+	ScopeRefundsWrite = "refunds:write"
+	// ScopeWrite allows every other write
+	ScopeWrite = "write"
+	// ScopeAdmin allows everything, including managing API keys
+	ScopeAdmin = "admin"
+)
+
+// SecretPrefix identifies API key secrets
+const SecretPrefix = "psk_"
+
+// Principal types
+const (
+	PrincipalAPIKey = "api_key"
+	PrincipalJWT    = "jwt"
+)
+
+var (
+	// ErrUnauthenticated is returned for missing, unknown, expired or revoked credentials
+	ErrUnauthenticated = errors.New("missing or invalid credentials")
+	// ErrScopeNotAllowed is returned when credentials lack the scope a call needs
+	ErrScopeNotAllowed = errors.New("credentials do not allow this action")
+	// ErrUnknownScope is returned when creating a key with an unsupported scope
+	ErrUnknownScope = errors.New("unknown scope")
+	// ErrKeyNotFound is returned when rotating or revoking a key that is not active
+	ErrKeyNotFound = errors.New("API key not found")
+)
+
+// Config controls authentication of API requests
+type Config struct {
+	Enabled       bool
+	JWTSecret     string        // HMAC secret for HS256 tokens; tokens are rejected without one
+	JWTIssuer     string        // Required iss claim, if set
+	JWTAudience   string        // Required aud claim, if set
+	RotationGrace time.Duration // How long a rotated key keeps working
+}
+
+// LoadConfig loads the authentication configuration from environment variables
+func LoadConfig() *Config {
+	config := &Config{
+		Enabled:       true,
+		JWTSecret:     os.Getenv("AUTH_JWT_SECRET"),
+		JWTIssuer:     os.Getenv("AUTH_JWT_ISSUER"),
+		JWTAudience:   os.Getenv("AUTH_JWT_AUDIENCE"),
+		RotationGrace: 24 * time.Hour,
+	}
+
+	if enabled, err := strconv.ParseBool(os.Getenv("AUTH_ENABLED")); err == nil {
+		config.Enabled = enabled
+	}
+	if hours, err := strconv.Atoi(os.Getenv("AUTH_KEY_ROTATION_GRACE_HOURS")); err == nil && hours >= 0 {
+		config.RotationGrace = time.Duration(hours) * time.Hour
+	}
+
+	return config
+}
+
+// APIKey is a long-lived credential. The secret is only returned when the
+// key is created or rotated.
+type APIKey struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Secret      string     `json:"secret,omitempty"`
+	SecretHint  string     `json:"secret_hint"`
+	Scopes      []string   `json:"scopes"`
+	TenantID    string     `json:"tenant_id,omitempty"`
+	CreatedBy   string     `json:"created_by,omitempty"`
+	RotatedFrom string     `json:"rotated_from,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// Active reports whether the key can still be used at the given time
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// Principal is the authenticated caller behind a request
+type Principal struct {
+	Type     string   `json:"type"`
+	ID       string   `json:"id"` // API key ID or token subject
+	TenantID string   `json:"tenant_id,omitempty"`
+	Scopes   []string `json:"scopes"`
+	Roles    []string `json:"roles,omitempty"` // From the roles claim of a bearer token
+}
+
+// Allows reports whether the principal grants a scope. The admin scope
+// grants every scope.
+func (p *Principal) Allows(scope string) bool {
+	for _, granted := range p.Scopes {
+		if granted == scope || granted == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// Fingerprint identifies the credential a call was made with, so raw
+// credentials never reach the database. It returns "" for no credential.
+func Fingerprint(credential string) string {
+	if credential == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(credential))
+	return "key_" + hex.EncodeToString(sum[:8])
+}
+
+// Store persists API keys by the hash of their secret
+type Store interface {
+	CreateAPIKey(ctx context.Context, key *APIKey, secretHash string) (*APIKey, error)
+	GetAPIKey(ctx context.Context, id string) (*APIKey, error)
+	GetAPIKeyBySecretHash(ctx context.Context, secretHash string) (*APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]*APIKey, error)
+	ExpireAPIKey(ctx context.Context, id string, expiresAt time.Time) (bool, error)
+	RevokeAPIKey(ctx context.Context, id string, revokedAt time.Time) (bool, error)
+}
+
+// IsScope reports whether a scope can be granted
+func IsScope(scope string) bool {
+	switch scope {
+	case ScopeRead, ScopeChargesWrite, ScopeRefundsWrite, ScopeWrite, ScopeAdmin:
+		return true
+	}
+	return false
+}
+
+// scopeRule requires a scope for writes to paths matching a pattern, where
+// "*" matches any one segment
+type scopeRule struct {
+	pattern string
+	scope   string
+}
+
+// writeScopes are checked in order; writes matching none need ScopeWrite
+var writeScopes = []scopeRule{
+	{"/composite-charges/*/refunds", ScopeRefundsWrite},
+	{"/composite-charges", ScopeChargesWrite},
+	{"/charges", ScopeChargesWrite},
+	{"/payment-intents", ScopeChargesWrite},
+	{"/authorizations", ScopeChargesWrite},
+	{"/refunds", ScopeRefundsWrite},
+	{"/refund-approvals", ScopeRefundsWrite},
+	{"/auto-refund-exclusions", ScopeRefundsWrite},
+	{"/graphql", ScopeRead}, // Only queries are served
+}
+
+// adminPaths need ScopeAdmin for reads and writes alike
+var adminPaths = []string{"/api-keys"}
+
+// RequiredScope returns the scope a request needs, given its method and its
+// path below the API prefix
+func RequiredScope(method, path string) string {
+	for _, pattern := range adminPaths {
+		if matchPath(pattern, path) {
+			return ScopeAdmin
+		}
+	}
+
+	switch method {
+	case "GET", "HEAD", "OPTIONS":
+		return ScopeRead
+	}
+
+	for _, rule := range writeScopes {
+		if matchPath(rule.pattern, path) {
+			return rule.scope
+		}
+	}
+	return ScopeWrite
+}
+
+// matchPath reports whether a path starts with the segments of a pattern
+func matchPath(pattern, path string) bool {
+	patternSegments := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(pathSegments) < len(patternSegments) {
+		return false
+	}
+
+	for i, segment := range patternSegments {
+		if segment != "*" && segment != pathSegments[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// clockSkew is how far token times may be off from ours
+const clockSkew = time.Minute
+
+// tokenHeader is the JOSE header of a bearer token
+type tokenHeader struct {
+	Algorithm string `json:"alg"`
+}
+
+// tokenClaims are the claims read from a bearer token. Scopes are given
+// either space-separated in scope or as a scopes array.
+type tokenClaims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud"`
+	ExpiresAt int64    `json:"exp"`
+	NotBefore int64    `json:"nbf"`
+	Scope     string   `json:"scope"`
+	Scopes    []string `json:"scopes"`
+	TenantID  string   `json:"tenant_id"`
+	Roles     []string `json:"roles"`
+}
+
+// audience is an aud claim, which may be a string or an array of strings
+type audience []string
+
+// UnmarshalJSON accepts a single audience or a list of them
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+// contains reports whether the audience includes a value
+func (a audience) contains(value string) bool {
+	for _, entry := range a {
+		if entry == value {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyToken checks an HS256 bearer token against the configured secret,
+// issuer and audience, and returns the principal it names. Tokens must
+// expire; other algorithms, including "none", are rejected.
+func verifyToken(token string, config *Config, now time.Time) (*Principal, error) {
+	if config.JWTSecret == "" {
+		return nil, ErrUnauthenticated
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrUnauthenticated
+	}
+
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil || header.Algorithm != "HS256" {
+		return nil, ErrUnauthenticated
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrUnauthenticated
+	}
+	mac := hmac.New(sha256.New, []byte(config.JWTSecret))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, ErrUnauthenticated
+	}
+
+	var claims tokenClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrUnauthenticated
+	}
+	if claims.Subject == "" || claims.ExpiresAt == 0 {
+		return nil, ErrUnauthenticated
+	}
+	if !now.Before(time.Unix(claims.ExpiresAt, 0).Add(clockSkew)) {
+		return nil, ErrUnauthenticated
+	}
+	if claims.NotBefore != 0 && now.Add(clockSkew).Before(time.Unix(claims.NotBefore, 0)) {
+		return nil, ErrUnauthenticated
+	}
+	if config.JWTIssuer != "" && claims.Issuer != config.JWTIssuer {
+		return nil, ErrUnauthenticated
+	}
+	if config.JWTAudience != "" && !claims.Audience.contains(config.JWTAudience) {
+		return nil, ErrUnauthenticated
+	}
+
+	scopes := claims.Scopes
+	if len(scopes) == 0 {
+		scopes = strings.Fields(claims.Scope)
+	}
+
+	return &Principal{
+		Type:     PrincipalJWT,
+		ID:       claims.Subject,
+		TenantID: claims.TenantID,
+		Scopes:   scopes,
+		Roles:    claims.Roles,
+	}, nil
+}
+
+// decodeSegment decodes a base64url JSON token segment
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,227 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// secretHintLength is how much of a secret is kept to tell keys apart
+const secretHintLength = len(SecretPrefix) + 8
+
+// Service issues API keys and authenticates API keys and bearer tokens
+type Service struct {
+	store  Store
+	config *Config
+	tracer trace.Tracer
+}
+
+// NewService creates a new auth service
+func NewService(store Store, config *Config) *Service {
+	if config == nil {
+		config = LoadConfig()
+	}
+
+	return &Service{
+		store:  store,
+		config: config,
+		tracer: otel.Tracer("payments.auth"),
+	}
+}
+
+// Enabled reports whether API requests must be authenticated
+func (s *Service) Enabled() bool {
+	return s.config.Enabled
+}
+
+// CreateKeyRequest asks for a new API key
+type CreateKeyRequest struct {
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	TenantID  string   `json:"tenant_id,omitempty"`
+	CreatedBy string   `json:"-"`
+}
+
+// CreateKey creates an API key. The returned key carries the secret, which
+// cannot be retrieved again.
+func (s *Service) CreateKey(ctx context.Context, request *CreateKeyRequest) (*APIKey, error) {
+	ctx, span := s.tracer.Start(ctx, "CreateKey")
+	defer span.End()
+
+	if request.Name == "" {
+		return nil, fmt.Errorf("name cannot be empty")
+	}
+	if len(request.Scopes) == 0 {
+		return nil, fmt.Errorf("at least one scope is required")
+	}
+	for _, scope := range request.Scopes {
+		if !IsScope(scope) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownScope, scope)
+		}
+	}
+
+	return s.issue(ctx, &APIKey{
+		Name:      request.Name,
+		Scopes:    request.Scopes,
+		TenantID:  request.TenantID,
+		CreatedBy: request.CreatedBy,
+	})
+}
+
+// Authenticate returns the principal behind an API key secret or a bearer token
+func (s *Service) Authenticate(ctx context.Context, credential string) (*Principal, error) {
+	ctx, span := s.tracer.Start(ctx, "Authenticate")
+	defer span.End()
+
+	if credential == "" {
+		return nil, ErrUnauthenticated
+	}
+	if !strings.HasPrefix(credential, SecretPrefix) {
+		return verifyToken(credential, s.config, time.Now())
+	}
+
+	key, err := s.store.GetAPIKeyBySecretHash(ctx, HashSecret(credential))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUnauthenticated
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !key.Active(time.Now()) {
+		return nil, ErrUnauthenticated
+	}
+
+	return &Principal{
+		Type:     PrincipalAPIKey,
+		ID:       key.ID,
+		TenantID: key.TenantID,
+		Scopes:   key.Scopes,
+	}, nil
+}
+
+// ListKeys returns every API key, without secrets
+func (s *Service) ListKeys(ctx context.Context) ([]*APIKey, error) {
+	ctx, span := s.tracer.Start(ctx, "ListKeys")
+	defer span.End()
+
+	return s.store.ListAPIKeys(ctx)
+}
+
+// GetKey returns an API key, without its secret
+func (s *Service) GetKey(ctx context.Context, id string) (*APIKey, error) {
+	ctx, span := s.tracer.Start(ctx, "GetKey")
+	defer span.End()
+
+	key, err := s.store.GetAPIKey(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// RotateKey issues a replacement for an active key with the same name,
+// scopes and tenant. The old key keeps working for the grace period, or the
+// configured grace period when grace is nil, so callers can switch over.
+func (s *Service) RotateKey(ctx context.Context, id string, grace *time.Duration, rotatedBy string) (*APIKey, error) {
+	ctx, span := s.tracer.Start(ctx, "RotateKey")
+	defer span.End()
+
+	old, err := s.GetKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if !old.Active(now) {
+		return nil, ErrKeyNotFound
+	}
+
+	expiresAt := now.Add(s.config.RotationGrace)
+	if grace != nil {
+		expiresAt = now.Add(max(*grace, 0))
+	}
+
+	key, err := s.issue(ctx, &APIKey{
+		Name:        old.Name,
+		Scopes:      old.Scopes,
+		TenantID:    old.TenantID,
+		CreatedBy:   rotatedBy,
+		RotatedFrom: old.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// An old key already due to expire sooner keeps its expiry
+	if old.ExpiresAt == nil || expiresAt.Before(*old.ExpiresAt) {
+		if _, err := s.store.ExpireAPIKey(ctx, old.ID, expiresAt); err != nil {
+			return nil, err
+		}
+	}
+
+	return key, nil
+}
+
+// RevokeKey invalidates a key immediately
+func (s *Service) RevokeKey(ctx context.Context, id string) error {
+	ctx, span := s.tracer.Start(ctx, "RevokeKey")
+	defer span.End()
+
+	revoked, err := s.store.RevokeAPIKey(ctx, id, time.Now())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrKeyNotFound
+	}
+
+	return nil
+}
+
+// issue generates a secret for a key and stores the key under its hash
+func (s *Service) issue(ctx context.Context, key *APIKey) (*APIKey, error) {
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	key.ID = fmt.Sprintf("apikey_%s", uuid.New().String())
+	key.SecretHint = secret[:secretHintLength]
+
+	created, err := s.store.CreateAPIKey(ctx, key, HashSecret(secret))
+	if err != nil {
+		return nil, err
+	}
+
+	created.Secret = secret
+	return created, nil
+}
+
+// HashSecret hashes a secret for storage and lookup
+func HashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// newSecret generates a random API key secret
+func newSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	return SecretPrefix + hex.EncodeToString(buf), nil
+}
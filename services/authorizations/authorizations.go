@@ -0,0 +1,115 @@
+package authorizations
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"time"
+
+	"apis/payments/services"
+	"apis/payments/services/config"
+)
+
+// Authorization statuses. An authorization is open while it holds the
+// customer's funds and ends captured, voided or expired.
+const (
+	StatusOpen     = "open"
+	StatusCaptured = "captured" // Captured in full, or by a final partial capture
+	StatusVoided   = "voided"   // Released on request or by the stale sweep
+	StatusExpired  = "expired"  // Lapsed at the provider before it was captured
+)
+
+// Event types emitted as authorizations close or are captured
+const (
+	EventCaptured = "payments.authorization.captured"
+	EventVoided   = "payments.authorization.voided"
+	EventExpired  = "payments.authorization.expired"
+)
+
+var (
+	// ErrAuthorizationNotFound is returned for charges with no tracked authorization
+	ErrAuthorizationNotFound = errors.New("authorization not found")
+	// ErrAuthorizationClosed is returned when capturing or voiding an authorization that is no longer open
+	ErrAuthorizationClosed = errors.New("authorization is no longer open")
+	// ErrAuthorizationExpired is returned when capturing an authorization past its expiry
+	ErrAuthorizationExpired = errors.New("authorization has expired")
+	// ErrExceedsAuthorization is returned for captures larger than the uncaptured amount
+	ErrExceedsAuthorization = errors.New("capture amount exceeds the uncaptured amount")
+	// ErrSingleCapture is returned when keeping open an authorization that can only be captured once
+	ErrSingleCapture = errors.New("authorization can only be captured once")
+)
+
+// Authorization tracks an uncaptured charge until it is captured, voided or
+// expires at the provider
+type Authorization struct {
+	ChargeID       string     `json:"charge_id"`
+	TenantID       string     `json:"tenant_id"`
+	Provider       string     `json:"provider"`
+	Amount         int64      `json:"amount"`
+	AmountCaptured int64      `json:"amount_captured"`
+	Currency       string     `json:"currency"`
+	Captures       int        `json:"captures"`
+	MultiCapture   bool       `json:"multi_capture"` // Can be captured in several parts
+	Status         string     `json:"status"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	ClosedAt       *time.Time `json:"closed_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// Open reports whether the authorization still holds funds
+func (a *Authorization) Open() bool {
+	return a.Status == StatusOpen
+}
+
+// Uncaptured returns the amount still available to capture
+func (a *Authorization) Uncaptured() int64 {
+	return a.Amount - a.AmountCaptured
+}
+
+// Filter narrows an authorization listing
+type Filter struct {
+	TenantID string
+	Status   string
+	Limit    int
+}
+
+// Config controls the sweep voiding stale authorizations
+type Config struct {
+	Enabled   bool
+	Margin    time.Duration // How long before expiry an authorization is stale
+	Interval  time.Duration // How often stale authorizations are swept
+	BatchSize int
+}
+
+// LoadConfig loads the authorization configuration from environment variables
+func LoadConfig() *Config {
+	config := &Config{
+		Enabled:   true,
+		Margin:    time.Duration(config.Int("AUTHORIZATION_VOID_MARGIN_HOURS", 12)) * time.Hour,
+		Interval:  time.Duration(config.Int("AUTHORIZATION_SWEEP_INTERVAL_MINUTES", 15)) * time.Minute,
+		BatchSize: config.Int("AUTHORIZATION_SWEEP_BATCH_SIZE", 200),
+	}
+
+	if enabled, err := strconv.ParseBool(os.Getenv("AUTHORIZATION_SWEEP_ENABLED")); err == nil {
+		config.Enabled = enabled
+	}
+
+	return config
+}
+
+// Store persists authorizations. Updates of closed authorizations return
+// sql.ErrNoRows.
+type Store interface {
+	TrackAuthorization(ctx context.Context, authorization *Authorization) (bool, error)
+	GetAuthorization(ctx context.Context, chargeID string) (*Authorization, error)
+	ListAuthorizations(ctx context.Context, filter Filter) ([]*Authorization, error)
+	ListStaleAuthorizations(ctx context.Context, expiringBefore time.Time, limit int) ([]*Authorization, error)
+	UpdateAuthorization(ctx context.Context, authorization *Authorization) (*Authorization, error)
+}
+
+// Gateways returns the gateway acting on a tenant's provider account
+type Gateways interface {
+	Gateway(ctx context.Context, tenantID, provider string) (services.PaymentGateway, error)
+}
@@ -0,0 +1,368 @@
+package authorizations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"apis/payments/services"
+	"apis/payments/services/events"
+	"apis/payments/services/jobs"
+	"apis/payments/services/tenancy"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Service tracks uncaptured authorizations, captures them in one or more
+// parts and voids those about to expire
+type Service struct {
+	store    Store
+	gateways Gateways
+	emitter  *events.Emitter
+	config   *Config
+	tracer   trace.Tracer
+}
+
+// NewService creates a new authorization service
+func NewService(store Store, gateways Gateways, emitter *events.Emitter, config *Config) *Service {
+	if config == nil {
+		config = LoadConfig()
+	}
+
+	return &Service{
+		store:    store,
+		gateways: gateways,
+		emitter:  emitter,
+		config:   config,
+		tracer:   otel.Tracer("payments.authorizations"),
+	}
+}
+
+// Track starts tracking a charge awaiting capture. The authorization expires
+// when the provider reports, or after the provider's authorization window.
+// Charges that are not awaiting capture return nil, as do charges already
+// tracked.
+func (s *Service) Track(ctx context.Context, tenantID string, charge *services.Charge, multiCapture bool) (*Authorization, error) {
+	ctx, span := s.tracer.Start(ctx, "Track")
+	defer span.End()
+
+	if charge.Status != "requires_capture" {
+		return nil, nil
+	}
+	capabilities, err := services.Capabilities(charge.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := charge.CreatedAt.Add(capabilities.AuthorizationWindow)
+	if charge.CaptureBefore != nil {
+		expiresAt = *charge.CaptureBefore
+	}
+
+	authorization := &Authorization{
+		ChargeID:     charge.ID,
+		TenantID:     tenantID,
+		Provider:     charge.Provider,
+		Amount:       charge.Amount,
+		Currency:     charge.Currency,
+		MultiCapture: multiCapture && capabilities.SupportsMultiCapture,
+		Status:       StatusOpen,
+		ExpiresAt:    expiresAt.UTC(),
+	}
+	tracked, err := s.store.TrackAuthorization(ctx, authorization)
+	if err != nil {
+		return nil, fmt.Errorf("failed to track authorization: %w", err)
+	}
+	if !tracked {
+		return nil, nil
+	}
+
+	return authorization, nil
+}
+
+// Capture captures part or, when the amount is zero, all of what remains of
+// an open authorization. KeepOpen leaves the rest of a multi-capture
+// authorization open for further captures; otherwise the capture is final
+// and the provider releases anything left uncaptured.
+func (s *Service) Capture(ctx context.Context, tenantID, chargeID string, request services.CaptureChargeRequest) (*Authorization, *services.Charge, error) {
+	ctx, span := s.tracer.Start(ctx, "Capture")
+	defer span.End()
+
+	authorization, err := s.open(ctx, tenantID, chargeID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if time.Now().After(authorization.ExpiresAt) {
+		return nil, nil, ErrAuthorizationExpired
+	}
+
+	amount := request.Amount
+	if amount == 0 {
+		amount = authorization.Uncaptured()
+	}
+	if amount < 0 || amount > authorization.Uncaptured() {
+		return nil, nil, ErrExceedsAuthorization
+	}
+	if request.KeepOpen && !authorization.MultiCapture {
+		return nil, nil, ErrSingleCapture
+	}
+
+	gateway, err := s.gateways.Gateway(ctx, tenantID, authorization.Provider)
+	if err != nil {
+		return nil, nil, err
+	}
+	charge, err := gateway.CaptureCharge(ctx, chargeID, services.CaptureChargeRequest{Amount: amount, KeepOpen: request.KeepOpen})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	authorization.AmountCaptured += amount
+	authorization.Captures++
+	if !request.KeepOpen || authorization.Uncaptured() == 0 {
+		s.close(authorization, StatusCaptured, time.Now())
+	}
+
+	updated, err := s.update(ctx, authorization)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.emit(ctx, EventCaptured, updated)
+
+	return updated, charge, nil
+}
+
+// Void releases an open authorization without capturing what remains of it
+func (s *Service) Void(ctx context.Context, tenantID, chargeID string) (*Authorization, *services.Charge, error) {
+	ctx, span := s.tracer.Start(ctx, "Void")
+	defer span.End()
+
+	authorization, err := s.open(ctx, tenantID, chargeID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	charge, err := s.void(ctx, authorization, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return authorization, charge, nil
+}
+
+// Sweep voids open authorizations that expire within the configured margin
+// of now, so funds are released before the provider lets them lapse. Those
+// that can no longer be voided after expiring are marked expired. It returns
+// how many authorizations were closed.
+func (s *Service) Sweep(ctx context.Context, now time.Time) (int, error) {
+	ctx, span := s.tracer.Start(ctx, "Sweep")
+	defer span.End()
+
+	if !s.config.Enabled {
+		return 0, nil
+	}
+
+	stale, err := s.store.ListStaleAuthorizations(ctx, now.Add(s.config.Margin), s.config.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list stale authorizations: %w", err)
+	}
+
+	closed := 0
+	for _, authorization := range stale {
+		// Gateways act on the provider account of the authorization's tenant
+		tenantCtx := tenancy.WithTenant(ctx, authorization.TenantID)
+
+		_, err := s.void(tenantCtx, authorization, now)
+		if err != nil && now.After(authorization.ExpiresAt) {
+			err = s.expire(tenantCtx, authorization, now)
+		}
+		if err != nil {
+			log.Printf("Failed to void stale authorization %s: %v", authorization.ChargeID, err)
+			continue
+		}
+		closed++
+	}
+
+	return closed, nil
+}
+
+// Captured records a capture made at the provider directly. Authorizations
+// that can only be captured once close; multi-capture ones close once
+// nothing is left to capture.
+func (s *Service) Captured(ctx context.Context, chargeID string, amountCaptured int64, at time.Time) error {
+	authorization, err := s.store.GetAuthorization(ctx, chargeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get authorization: %w", err)
+	}
+	if !authorization.Open() || (authorization.MultiCapture && amountCaptured <= authorization.AmountCaptured) {
+		return nil
+	}
+
+	authorization.AmountCaptured = max(authorization.AmountCaptured, amountCaptured)
+	if !authorization.MultiCapture || authorization.Uncaptured() <= 0 {
+		s.close(authorization, StatusCaptured, at)
+	}
+
+	updated, err := s.update(ctx, authorization)
+	if err != nil {
+		return err
+	}
+	s.emit(ctx, EventCaptured, updated)
+	return nil
+}
+
+// Released records an authorization the provider voided or let expire
+func (s *Service) Released(ctx context.Context, chargeID, status string, at time.Time) error {
+	authorization, err := s.store.GetAuthorization(ctx, chargeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get authorization: %w", err)
+	}
+	if !authorization.Open() {
+		return nil
+	}
+
+	s.close(authorization, status, at)
+	updated, err := s.update(ctx, authorization)
+	if err != nil {
+		return err
+	}
+	s.emit(ctx, eventForStatus(status), updated)
+	return nil
+}
+
+// Get retrieves a tenant's authorization
+func (s *Service) Get(ctx context.Context, tenantID, chargeID string) (*Authorization, error) {
+	authorization, err := s.store.GetAuthorization(ctx, chargeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAuthorizationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if authorization.TenantID != tenantID {
+		return nil, ErrAuthorizationNotFound
+	}
+	return authorization, nil
+}
+
+// List lists authorizations, newest first
+func (s *Service) List(ctx context.Context, filter Filter) ([]*Authorization, error) {
+	if filter.Limit <= 0 || filter.Limit > 500 {
+		filter.Limit = 100
+	}
+	return s.store.ListAuthorizations(ctx, filter)
+}
+
+// Job sweeps stale authorizations every configured interval. It returns
+// nil unless the sweep is enabled.
+func (s *Service) Job() *jobs.Job {
+	if !s.config.Enabled {
+		return nil
+	}
+
+	return &jobs.Job{
+		Name:     "authorization_sweep",
+		Schedule: jobs.Every(s.config.Interval),
+		Run: func(ctx context.Context) error {
+			closed, err := s.Sweep(ctx, time.Now())
+			if closed > 0 {
+				log.Printf("Authorization sweep released %d stale authorizations", closed)
+			}
+			return err
+		},
+	}
+}
+
+// open returns a tenant's authorization if it is still open
+func (s *Service) open(ctx context.Context, tenantID, chargeID string) (*Authorization, error) {
+	authorization, err := s.Get(ctx, tenantID, chargeID)
+	if err != nil {
+		return nil, err
+	}
+	if !authorization.Open() {
+		return nil, ErrAuthorizationClosed
+	}
+	return authorization, nil
+}
+
+// void releases an authorization at its provider and records it voided
+func (s *Service) void(ctx context.Context, authorization *Authorization, now time.Time) (*services.Charge, error) {
+	gateway, err := s.gateways.Gateway(ctx, authorization.TenantID, authorization.Provider)
+	if err != nil {
+		return nil, err
+	}
+	charge, err := gateway.VoidCharge(ctx, authorization.ChargeID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.close(authorization, StatusVoided, now)
+	updated, err := s.update(ctx, authorization)
+	if err != nil {
+		return nil, err
+	}
+	*authorization = *updated
+	s.emit(ctx, EventVoided, updated)
+
+	return charge, nil
+}
+
+// expire records an authorization that lapsed at its provider
+func (s *Service) expire(ctx context.Context, authorization *Authorization, now time.Time) error {
+	s.close(authorization, StatusExpired, now)
+	updated, err := s.update(ctx, authorization)
+	if err != nil {
+		return err
+	}
+	s.emit(ctx, EventExpired, updated)
+	return nil
+}
+
+// close moves an authorization to a closed status
+func (s *Service) close(authorization *Authorization, status string, at time.Time) {
+	authorization.Status = status
+	closedAt := at.UTC()
+	authorization.ClosedAt = &closedAt
+}
+
+// update stores an open authorization's progress. Authorizations closed
+// meanwhile return ErrAuthorizationClosed.
+func (s *Service) update(ctx context.Context, authorization *Authorization) (*Authorization, error) {
+	updated, err := s.store.UpdateAuthorization(ctx, authorization)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAuthorizationClosed
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update authorization: %w", err)
+	}
+	return updated, nil
+}
+
+// emit publishes an authorization event with the charge as subject.
+// Publishing failures are logged rather than failing the provider call
+// that has already been made.
+func (s *Service) emit(ctx context.Context, eventType string, authorization *Authorization) {
+	if s.emitter == nil {
+		return
+	}
+	if err := s.emitter.Emit(ctx, "authorizations", eventType, authorization.ChargeID, authorization); err != nil {
+		log.Printf("Failed to emit %s for %s: %v", eventType, authorization.ChargeID, err)
+	}
+}
+
+// eventForStatus returns the event emitted on closing with a status
+func eventForStatus(status string) string {
+	if status == StatusExpired {
+		return EventExpired
+	}
+	return EventVoided
+}
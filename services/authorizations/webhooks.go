@@ -0,0 +1,93 @@
+package authorizations
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"apis/payments/services"
+	"apis/payments/services/stripe"
+	"apis/payments/services/tenancy"
+
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// RegisterWebhookHandlers tracks charges authorized without capture and
+// closes their authorizations when they are captured, released or expire
+// outside the API
+func (s *Service) RegisterWebhookHandlers(webhooks *stripe.WebhookService) {
+	webhooks.On(stripego.EventTypeChargeSucceeded, func(ctx context.Context, event stripego.Event) error {
+		charge, err := parseCharge(event)
+		if err != nil {
+			return err
+		}
+		_, err = s.TrackStripeCharge(ctx, tenancy.ID(ctx), charge)
+		return err
+	})
+
+	webhooks.On(stripego.EventTypeChargeCaptured, func(ctx context.Context, event stripego.Event) error {
+		charge, err := parseCharge(event)
+		if err != nil {
+			return err
+		}
+		return s.Captured(ctx, charge.ID, charge.AmountCaptured, time.Unix(event.Created, 0))
+	})
+
+	webhooks.On(stripego.EventTypeChargeExpired, func(ctx context.Context, event stripego.Event) error {
+		charge, err := parseCharge(event)
+		if err != nil {
+			return err
+		}
+		return s.Released(ctx, charge.ID, StatusExpired, time.Unix(event.Created, 0))
+	})
+
+	// Uncaptured charges are refunded when their payment intent is canceled
+	webhooks.On(stripego.EventTypeChargeRefunded, func(ctx context.Context, event stripego.Event) error {
+		charge, err := parseCharge(event)
+		if err != nil {
+			return err
+		}
+		if charge.Captured {
+			return nil
+		}
+		return s.Released(ctx, charge.ID, StatusVoided, time.Unix(event.Created, 0))
+	})
+}
+
+// TrackStripeCharge starts tracking a Stripe charge authorized without
+// capture. Captured charges return nil.
+func (s *Service) TrackStripeCharge(ctx context.Context, tenantID string, charge *stripe.Charge) (*Authorization, error) {
+	if charge.Captured || charge.Status != string(stripego.ChargeStatusSucceeded) {
+		return nil, nil
+	}
+	return s.Track(ctx, tenantID, authorizedCharge(charge), charge.MultiCapture)
+}
+
+// parseCharge reads the charge of a charge event
+func parseCharge(event stripego.Event) (*stripe.Charge, error) {
+	var charge stripego.Charge
+	if err := json.Unmarshal(event.Data.Raw, &charge); err != nil {
+		return nil, fmt.Errorf("failed to parse charge: %w", err)
+	}
+	return stripe.ConvertCharge(&charge), nil
+}
+
+// authorizedCharge describes an uncaptured Stripe charge as a gateway charge
+func authorizedCharge(charge *stripe.Charge) *services.Charge {
+	authorized := &services.Charge{
+		ID:         charge.ID,
+		Amount:     charge.Amount,
+		Currency:   charge.Currency,
+		CustomerID: charge.CustomerID,
+		Status:     "requires_capture",
+		CreatedAt:  time.Unix(charge.Created, 0),
+		ProviderID: charge.ID,
+		Provider:   "stripe",
+	}
+	if charge.CaptureBefore > 0 {
+		captureBefore := time.Unix(charge.CaptureBefore, 0)
+		authorized.CaptureBefore = &captureBefore
+	}
+	return authorized
+}
@@ -0,0 +1,100 @@
+package autorefund
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"time"
+
+	"apis/payments/services/stripe"
+)
+
+// Auto-refund statuses
+const (
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Event types emitted for automatic refunds
+const (
+	EventAutoRefundCreated = "payments.auto_refund.created"
+	EventAutoRefundFailed  = "payments.auto_refund.failed"
+)
+
+// LedgerReferenceType identifies auto-refund ledger entries
+const LedgerReferenceType = "auto_refund"
+
+// Balance is a customer's unclaimed cash balance in one currency. FundedAt is
+// when the oldest unapplied funds arrived and is nil when nothing is unclaimed.
+type Balance struct {
+	CustomerID string     `json:"customer_id"`
+	Currency   string     `json:"currency"`
+	Amount     int64      `json:"amount"`
+	FundedAt   *time.Time `json:"funded_at,omitempty"`
+}
+
+// Refund records an automatic refund attempt
+type Refund struct {
+	ID            string    `json:"id"`
+	CustomerID    string    `json:"customer_id"`
+	Currency      string    `json:"currency"`
+	Amount        int64     `json:"amount"`
+	RefundID      string    `json:"refund_id,omitempty"` // Provider refund ID
+	Status        string    `json:"status"`
+	FailureReason string    `json:"failure_reason,omitempty"`
+	FundedAt      time.Time `json:"funded_at"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Exclusion keeps a customer's unclaimed funds from being refunded automatically
+type Exclusion struct {
+	CustomerID string    `json:"customer_id"`
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Config controls the auto-refund scheduler
+type Config struct {
+	Enabled  bool
+	Window   time.Duration // How long funds may stay unclaimed
+	Interval time.Duration // How often due balances are swept
+}
+
+// LoadConfig loads the auto-refund configuration from environment variables
+func LoadConfig() *Config {
+	config := &Config{
+		Enabled:  false,
+		Window:   30 * 24 * time.Hour,
+		Interval: time.Hour,
+	}
+
+	if enabled, err := strconv.ParseBool(os.Getenv("AUTO_REFUND_ENABLED")); err == nil {
+		config.Enabled = enabled
+	}
+	if days, err := strconv.Atoi(os.Getenv("AUTO_REFUND_WINDOW_DAYS")); err == nil && days > 0 {
+		config.Window = time.Duration(days) * 24 * time.Hour
+	}
+	if minutes, err := strconv.Atoi(os.Getenv("AUTO_REFUND_INTERVAL_MINUTES")); err == nil && minutes > 0 {
+		config.Interval = time.Duration(minutes) * time.Minute
+	}
+
+	return config
+}
+
+// Store persists unclaimed balances, auto-refunds and exclusions
+type Store interface {
+	GetUnclaimedBalance(ctx context.Context, customerID, currency string) (*Balance, error)
+	UpsertUnclaimedBalance(ctx context.Context, balance *Balance) error
+	ListDueUnclaimedBalances(ctx context.Context, fundedBefore time.Time) ([]*Balance, error)
+	CreateAutoRefund(ctx context.Context, refund *Refund) (*Refund, error)
+	ListAutoRefunds(ctx context.Context, customerID string, limit int) ([]*Refund, error)
+	GetAutoRefundExclusion(ctx context.Context, customerID string) (*Exclusion, error)
+	ListAutoRefundExclusions(ctx context.Context) ([]*Exclusion, error)
+	UpsertAutoRefundExclusion(ctx context.Context, exclusion *Exclusion) (*Exclusion, error)
+	DeleteAutoRefundExclusion(ctx context.Context, customerID string) (bool, error)
+}
+
+// Refunder returns cash balance funds to customers at the provider
+type Refunder interface {
+	RefundCashBalance(ctx context.Context, request *stripe.CashBalanceRefundRequest) (*stripe.Refund, error)
+}
@@ -0,0 +1,267 @@
+package autorefund
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"apis/payments/services/events"
+	"apis/payments/services/ledger"
+	"apis/payments/services/stripe"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Service refunds customer funds that stay unclaimed past the configured window
+type Service struct {
+	store    Store
+	refunder Refunder
+	ledger   *ledger.Service
+	emitter  *events.Emitter
+	config   *Config
+	tracer   trace.Tracer
+}
+
+// NewService creates a new auto-refund service
+func NewService(store Store, refunder Refunder, ledgerService *ledger.Service, emitter *events.Emitter, config *Config) *Service {
+	if config == nil {
+		config = LoadConfig()
+	}
+
+	return &Service{
+		store:    store,
+		refunder: refunder,
+		ledger:   ledgerService,
+		emitter:  emitter,
+		config:   config,
+		tracer:   otel.Tracer("payments.autorefund"),
+	}
+}
+
+// UpdateBalance records a customer's cash balance after a provider cash
+// balance transaction. The funding time is kept while funds remain
+// unclaimed so partial applications don't restart the window.
+func (s *Service) UpdateBalance(ctx context.Context, customerID, currency string, endingBalance int64, at time.Time) error {
+	ctx, span := s.tracer.Start(ctx, "UpdateBalance")
+	defer span.End()
+
+	balance := &Balance{CustomerID: customerID, Currency: currency, Amount: endingBalance}
+	if endingBalance > 0 {
+		existing, err := s.store.GetUnclaimedBalance(ctx, customerID, currency)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to get unclaimed balance: %w", err)
+		}
+		if existing != nil && existing.Amount > 0 && existing.FundedAt != nil {
+			balance.FundedAt = existing.FundedAt
+		} else {
+			balance.FundedAt = &at
+		}
+	}
+
+	return s.store.UpsertUnclaimedBalance(ctx, balance)
+}
+
+// Sweep refunds every balance unclaimed since before now minus the window,
+// skipping excluded customers. Failed refunds are recorded and retried on
+// the next sweep.
+func (s *Service) Sweep(ctx context.Context, now time.Time) ([]*Refund, error) {
+	ctx, span := s.tracer.Start(ctx, "Sweep")
+	defer span.End()
+
+	balances, err := s.store.ListDueUnclaimedBalances(ctx, now.Add(-s.config.Window))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unclaimed balances: %w", err)
+	}
+
+	var refunds []*Refund
+	for _, balance := range balances {
+		excluded, err := s.isExcluded(ctx, balance.CustomerID)
+		if err != nil {
+			return refunds, err
+		}
+		if excluded {
+			continue
+		}
+
+		refund, err := s.refund(ctx, balance)
+		if err != nil {
+			return refunds, err
+		}
+		refunds = append(refunds, refund)
+	}
+
+	return refunds, nil
+}
+
+// refund returns one unclaimed balance to the customer
+func (s *Service) refund(ctx context.Context, balance *Balance) (*Refund, error) {
+	record := &Refund{
+		ID:         fmt.Sprintf("arf_%s", uuid.New().String()),
+		CustomerID: balance.CustomerID,
+		Currency:   balance.Currency,
+		Amount:     balance.Amount,
+		FundedAt:   *balance.FundedAt,
+	}
+
+	providerRefund, err := s.refunder.RefundCashBalance(ctx, &stripe.CashBalanceRefundRequest{
+		CustomerID: balance.CustomerID,
+		Currency:   balance.Currency,
+		Amount:     balance.Amount,
+		// Replicas sweeping the same balance create a single refund
+		IdempotencyKey: fmt.Sprintf("auto-refund-%s-%s-%d-%d", balance.CustomerID, balance.Currency, balance.FundedAt.Unix(), balance.Amount),
+		Metadata:       map[string]string{"auto_refund_id": record.ID},
+	})
+	if err != nil {
+		record.Status = StatusFailed
+		record.FailureReason = err.Error()
+	} else {
+		record.Status = StatusSucceeded
+		record.RefundID = providerRefund.ID
+	}
+
+	record, err = s.store.CreateAutoRefund(ctx, record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record auto-refund: %w", err)
+	}
+
+	if record.Status == StatusFailed {
+		log.Printf("Auto-refund of %d %s for customer %s failed: %s", record.Amount, record.Currency, record.CustomerID, record.FailureReason)
+		s.emit(ctx, EventAutoRefundFailed, record)
+		return record, nil
+	}
+
+	// The cash balance webhook will confirm the new balance; clear it now so
+	// the next sweep doesn't pick it up again
+	if err := s.store.UpsertUnclaimedBalance(ctx, &Balance{CustomerID: balance.CustomerID, Currency: balance.Currency}); err != nil {
+		return nil, fmt.Errorf("failed to clear unclaimed balance: %w", err)
+	}
+
+	if err := s.ledger.Post(ctx, &ledger.Entry{
+		ID:            fmt.Sprintf("le_%s", uuid.New().String()),
+		DebitAccount:  ledger.AccountUnclaimedFunds,
+		CreditAccount: ledger.AccountProviderBalance,
+		Amount:        record.Amount,
+		Currency:      record.Currency,
+		ReferenceType: LedgerReferenceType,
+		ReferenceID:   record.ID,
+		Description:   fmt.Sprintf("Automatic refund of unclaimed funds to %s", record.CustomerID),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to post auto-refund ledger entry: %w", err)
+	}
+
+	s.emit(ctx, EventAutoRefundCreated, record)
+	return record, nil
+}
+
+// emit publishes an auto-refund event. Publishing failures are logged rather
+// than failing a refund that has already been issued.
+func (s *Service) emit(ctx context.Context, eventType string, refund *Refund) {
+	if s.emitter == nil {
+		return
+	}
+	if err := s.emitter.Emit(ctx, "auto-refunds", eventType, refund.ID, refund); err != nil {
+		log.Printf("Failed to emit %s for %s: %v", eventType, refund.ID, err)
+	}
+}
+
+// isExcluded reports whether a customer is on the exclusion list
+func (s *Service) isExcluded(ctx context.Context, customerID string) (bool, error) {
+	_, err := s.store.GetAutoRefundExclusion(ctx, customerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check auto-refund exclusion: %w", err)
+	}
+	return true, nil
+}
+
+// ListRefunds returns recent auto-refunds, optionally for one customer
+func (s *Service) ListRefunds(ctx context.Context, customerID string, limit int) ([]*Refund, error) {
+	ctx, span := s.tracer.Start(ctx, "ListRefunds")
+	defer span.End()
+
+	if limit <= 0 || limit > 100 {
+		limit = 100
+	}
+
+	return s.store.ListAutoRefunds(ctx, customerID, limit)
+}
+
+// ListExclusions returns every excluded customer
+func (s *Service) ListExclusions(ctx context.Context) ([]*Exclusion, error) {
+	ctx, span := s.tracer.Start(ctx, "ListExclusions")
+	defer span.End()
+
+	return s.store.ListAutoRefundExclusions(ctx)
+}
+
+// Exclude adds a customer to the exclusion list
+func (s *Service) Exclude(ctx context.Context, customerID, reason string) (*Exclusion, error) {
+	ctx, span := s.tracer.Start(ctx, "Exclude")
+	defer span.End()
+
+	if customerID == "" {
+		return nil, fmt.Errorf("customer ID cannot be empty")
+	}
+
+	return s.store.UpsertAutoRefundExclusion(ctx, &Exclusion{CustomerID: customerID, Reason: reason})
+}
+
+// Include removes a customer from the exclusion list
+func (s *Service) Include(ctx context.Context, customerID string) error {
+	ctx, span := s.tracer.Start(ctx, "Include")
+	defer span.End()
+
+	removed, err := s.store.DeleteAutoRefundExclusion(ctx, customerID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return fmt.Errorf("customer %s is not excluded: %w", customerID, sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+// Start sweeps due balances every configured interval until the returned
+// stop function is called. It does nothing unless auto-refunds are enabled.
+func (s *Service) Start() (stop func()) {
+	if !s.config.Enabled {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(s.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				refunds, err := s.Sweep(context.Background(), time.Now())
+				if err != nil {
+					log.Printf("Auto-refund sweep failed: %v", err)
+				}
+				if len(refunds) > 0 {
+					log.Printf("Auto-refund sweep refunded %d unclaimed balances", len(refunds))
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
@@ -0,0 +1,28 @@
+package autorefund
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"apis/payments/services/stripe"
+
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// RegisterWebhookHandlers keeps unclaimed balances in step with customer
+// cash balance transactions
+func (s *Service) RegisterWebhookHandlers(webhooks *stripe.WebhookService) {
+	webhooks.On(stripego.EventTypeCustomerCashBalanceTransactionCreated, func(ctx context.Context, event stripego.Event) error {
+		var transaction stripego.CustomerCashBalanceTransaction
+		if err := json.Unmarshal(event.Data.Raw, &transaction); err != nil {
+			return fmt.Errorf("failed to parse cash balance transaction: %w", err)
+		}
+		if transaction.Customer == nil {
+			return nil
+		}
+
+		return s.UpdateBalance(ctx, transaction.Customer.ID, string(transaction.Currency), transaction.EndingBalance, time.Unix(transaction.Created, 0).UTC())
+	})
+}
@@ -0,0 +1,218 @@
+package backpressure
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"apis/payments/services/config"
+)
+
+// Reasons an instance sheds webhook deliveries
+const (
+	// ReasonSaturated means too many deliveries are already being processed
+	ReasonSaturated = "saturated"
+	// ReasonDatabaseSlow means the database is responding too slowly
+	ReasonDatabaseSlow = "database_slow"
+)
+
+// latencyWeight is how much each probe moves the smoothed database latency
+const latencyWeight = 0.3
+
+// Config controls when webhook deliveries are shed and how long providers
+// are asked to wait before redelivering
+type Config struct {
+	// MaxInFlight is the number of deliveries processed at once before new
+	// ones are shed
+	MaxInFlight int
+	// MaxDBLatency is the smoothed database latency above which deliveries are shed
+	MaxDBLatency time.Duration
+	// ProbeInterval is how often database latency is measured
+	ProbeInterval time.Duration
+	// MinRetryAfter and MaxRetryAfter bound the Retry-After sent when shedding
+	MinRetryAfter time.Duration
+	MaxRetryAfter time.Duration
+}
+
+// LoadConfig loads backpressure settings from environment variables
+func LoadConfig() *Config {
+	return &Config{
+		MaxInFlight:   config.Int("WEBHOOK_MAX_IN_FLIGHT", 64),
+		MaxDBLatency:  time.Duration(config.Int("WEBHOOK_MAX_DB_LATENCY_MS", 500)) * time.Millisecond,
+		ProbeInterval: time.Duration(config.Int("WEBHOOK_DB_PROBE_INTERVAL_MS", 5000)) * time.Millisecond,
+		MinRetryAfter: time.Duration(config.Int("WEBHOOK_RETRY_AFTER_MIN_SECONDS", 5)) * time.Second,
+		MaxRetryAfter: time.Duration(config.Int("WEBHOOK_RETRY_AFTER_MAX_SECONDS", 300)) * time.Second,
+	}
+}
+
+// Rejection tells a provider to redeliver later
+type Rejection struct {
+	Reason     string
+	RetryAfter time.Duration
+}
+
+// Status is a snapshot of the backpressure signals
+type Status struct {
+	InFlight    int     `json:"in_flight"`
+	MaxInFlight int     `json:"max_in_flight"`
+	DBLatencyMS float64 `json:"db_latency_ms"`
+	Shedding    string  `json:"shedding,omitempty"`
+}
+
+// Probe measures the database, e.g. by pinging it
+type Probe func(ctx context.Context) error
+
+// Monitor admits webhook deliveries while the instance keeps up and sheds
+// them with a Retry-After once it is saturated, so provider redelivery
+// spreads the load out instead of piling onto a struggling instance
+type Monitor struct {
+	config *Config
+
+	mu        sync.Mutex
+	inFlight  int
+	dbLatency time.Duration
+	jitter    func(limit time.Duration) time.Duration
+}
+
+// NewMonitor creates a monitor with nothing in flight and no latency measured
+func NewMonitor(config *Config) *Monitor {
+	return &Monitor{
+		config: config,
+		jitter: func(limit time.Duration) time.Duration {
+			if limit <= 0 {
+				return 0
+			}
+			return time.Duration(rand.Int63n(int64(limit)))
+		},
+	}
+}
+
+// Acquire admits a delivery, returning a func that must be called once it
+// has been processed, or rejects it when the instance is saturated
+func (m *Monitor) Acquire() (release func(), rejection *Rejection) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if rejection := m.check(); rejection != nil {
+		return nil, rejection
+	}
+
+	m.inFlight++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			m.inFlight--
+		})
+	}, nil
+}
+
+// ObserveDBLatency records a database latency measurement
+func (m *Monitor) ObserveDBLatency(latency time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.dbLatency == 0 {
+		m.dbLatency = latency
+		return
+	}
+	m.dbLatency = time.Duration(latencyWeight*float64(latency) + (1-latencyWeight)*float64(m.dbLatency))
+}
+
+// Status returns the current signals and whether deliveries are being shed
+func (m *Monitor) Status() Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	status := Status{
+		InFlight:    m.inFlight,
+		MaxInFlight: m.config.MaxInFlight,
+		DBLatencyMS: float64(m.dbLatency) / float64(time.Millisecond),
+	}
+	if rejection := m.check(); rejection != nil {
+		status.Shedding = rejection.Reason
+	}
+	return status
+}
+
+// Start probes the database in the background until stopped. A failed
+// probe counts as twice the latency limit so an unreachable database sheds
+// deliveries after a few probes.
+func (m *Monitor) Start(probe Probe) (stop func()) {
+	if probe == nil || m.config.ProbeInterval <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(m.config.ProbeInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				m.runProbe(probe)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// runProbe measures one probe, bounded by the probe interval
+func (m *Monitor) runProbe(probe Probe) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.config.ProbeInterval)
+	defer cancel()
+
+	started := time.Now()
+	err := probe(ctx)
+	latency := time.Since(started)
+	if err != nil {
+		log.Printf("Backpressure database probe failed: %v", err)
+		latency = max(latency, 2*m.config.MaxDBLatency)
+	}
+
+	m.ObserveDBLatency(latency)
+}
+
+// check returns a rejection when a signal is over its limit. The wait grows
+// with how far over the limit the signal is, plus jitter so rejected
+// deliveries do not all come back at once. Callers hold the lock.
+func (m *Monitor) check() *Rejection {
+	if m.config.MaxDBLatency > 0 && m.dbLatency > m.config.MaxDBLatency {
+		return &Rejection{
+			Reason:     ReasonDatabaseSlow,
+			RetryAfter: m.retryAfter(float64(m.dbLatency) / float64(m.config.MaxDBLatency)),
+		}
+	}
+
+	if m.config.MaxInFlight > 0 && m.inFlight >= m.config.MaxInFlight {
+		return &Rejection{
+			Reason:     ReasonSaturated,
+			RetryAfter: m.retryAfter(float64(m.inFlight+1) / float64(m.config.MaxInFlight)),
+		}
+	}
+
+	return nil
+}
+
+// retryAfter scales the minimum wait by the overload ratio, adds up to half
+// again as jitter, and caps it at the maximum wait
+func (m *Monitor) retryAfter(overload float64) time.Duration {
+	wait := time.Duration(float64(m.config.MinRetryAfter) * overload)
+	wait += m.jitter(wait / 2)
+
+	return min(max(wait, m.config.MinRetryAfter), m.config.MaxRetryAfter)
+}
@@ -0,0 +1,84 @@
+package bankdebits
+
+import (
+	"context"
+
+	"apis/payments/services/events"
+	"apis/payments/services/stripe"
+)
+
+// Event types emitted as bank debits settle and bank accounts are verified
+const (
+	EventPending              = "payments.bank_debit.pending"
+	EventSettled              = "payments.bank_debit.settled"
+	EventFailed               = "payments.bank_debit.failed"
+	EventVerificationRequired = "payments.bank_account.verification_required"
+	EventVerified             = "payments.bank_account.verified"
+	EventVerificationFailed   = "payments.bank_account.verification_failed"
+	EventMandateInactive      = "payments.mandate.inactive"
+)
+
+// chargeEvents maps the provider's charge statuses to settlement events
+var chargeEvents = map[string]string{
+	"pending":   EventPending,
+	"succeeded": EventSettled,
+	"failed":    EventFailed,
+}
+
+// Service publishes the asynchronous progress of bank debits: charges
+// settling or being returned days after they were made, micro-deposit
+// verification of bank accounts, and mandates the customer or bank revoked
+type Service struct {
+	emitter *events.Emitter
+}
+
+// NewService creates a new bank debit service
+func NewService(emitter *events.Emitter) *Service {
+	return &Service{emitter: emitter}
+}
+
+// ChargeUpdated publishes the settlement status of a bank debit charge.
+// Charges paid otherwise are ignored.
+func (s *Service) ChargeUpdated(ctx context.Context, charge *stripe.Charge) error {
+	if !stripe.IsBankDebit(charge.PaymentMethodType) {
+		return nil
+	}
+
+	eventType, ok := chargeEvents[charge.Status]
+	if !ok {
+		return nil
+	}
+
+	return s.emitter.Emit(ctx, "bank_debits", eventType, charge.ID, charge)
+}
+
+// SetupIntentUpdated publishes the verification status of a bank account
+// being saved. Setup intents for other payment methods are ignored.
+func (s *Service) SetupIntentUpdated(ctx context.Context, intent *stripe.SetupIntent, paymentMethodType string) error {
+	if !stripe.IsBankDebit(paymentMethodType) {
+		return nil
+	}
+
+	var eventType string
+	switch {
+	case intent.Status == stripe.SetupIntentSucceeded:
+		eventType = EventVerified
+	case intent.Microdeposits != nil:
+		eventType = EventVerificationRequired
+	case intent.LastError != "":
+		eventType = EventVerificationFailed
+	default:
+		return nil
+	}
+
+	return s.emitter.Emit(ctx, "bank_accounts", eventType, intent.ID, intent)
+}
+
+// MandateUpdated publishes mandates that can no longer be debited
+func (s *Service) MandateUpdated(ctx context.Context, mandate *stripe.Mandate) error {
+	if mandate.Status != "inactive" {
+		return nil
+	}
+
+	return s.emitter.Emit(ctx, "mandates", EventMandateInactive, mandate.ID, mandate)
+}
@@ -0,0 +1,70 @@
+package bankdebits
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"apis/payments/services/stripe"
+
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// settlementEvents are the provider events that carry a charge's settlement status
+var settlementEvents = []stripego.EventType{
+	stripego.EventTypeChargePending,
+	stripego.EventTypeChargeSucceeded,
+	stripego.EventTypeChargeFailed,
+}
+
+// verificationEvents are the provider events that carry a setup intent's status
+var verificationEvents = []stripego.EventType{
+	stripego.EventTypeSetupIntentRequiresAction,
+	stripego.EventTypeSetupIntentSucceeded,
+	stripego.EventTypeSetupIntentSetupFailed,
+}
+
+// RegisterWebhookHandlers publishes bank debit progress from charge, setup
+// intent and mandate events. A failed publish fails the webhook, which the
+// provider then redelivers.
+func (s *Service) RegisterWebhookHandlers(webhooks *stripe.WebhookService) {
+	for _, eventType := range settlementEvents {
+		webhooks.On(eventType, func(ctx context.Context, event stripego.Event) error {
+			var charge stripego.Charge
+			if err := json.Unmarshal(event.Data.Raw, &charge); err != nil {
+				return fmt.Errorf("failed to parse charge: %w", err)
+			}
+
+			return s.ChargeUpdated(ctx, stripe.ConvertCharge(&charge))
+		})
+	}
+
+	for _, eventType := range verificationEvents {
+		webhooks.On(eventType, func(ctx context.Context, event stripego.Event) error {
+			var intent stripego.SetupIntent
+			if err := json.Unmarshal(event.Data.Raw, &intent); err != nil {
+				return fmt.Errorf("failed to parse setup intent: %w", err)
+			}
+
+			// Intents confirmed with a bank debit name only that type
+			var paymentMethodType string
+			if len(intent.PaymentMethodTypes) == 1 {
+				paymentMethodType = intent.PaymentMethodTypes[0]
+			}
+			if intent.LastSetupError != nil && intent.LastSetupError.PaymentMethod != nil {
+				paymentMethodType = string(intent.LastSetupError.PaymentMethod.Type)
+			}
+
+			return s.SetupIntentUpdated(ctx, stripe.ConvertSetupIntent(&intent), paymentMethodType)
+		})
+	}
+
+	webhooks.On(stripego.EventTypeMandateUpdated, func(ctx context.Context, event stripego.Event) error {
+		var mandate stripego.Mandate
+		if err := json.Unmarshal(event.Data.Raw, &mandate); err != nil {
+			return fmt.Errorf("failed to parse mandate: %w", err)
+		}
+
+		return s.MandateUpdated(ctx, stripe.ConvertMandate(&mandate))
+	})
+}
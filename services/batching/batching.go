@@ -0,0 +1,208 @@
+package batching
+
+import (
+	"sync"
+	"time"
+
+	"apis/payments/services/config"
+)
+
+// Limits bounds how a controller adapts batch size and concurrency. Sizes
+// grow additively while batches finish within TargetLatency and under
+// MaxErrorRate, and shrink by Backoff as soon as one does not (AIMD).
+type Limits struct {
+	MinSize        int
+	MaxSize        int
+	InitialSize    int
+	Step           int // Items added to the batch size after each healthy batch
+	MinConcurrency int
+	MaxConcurrency int
+	TargetLatency  time.Duration
+	MaxErrorRate   float64
+	Backoff        float64 // Factor applied to size and concurrency on congestion
+}
+
+// DefaultLimits returns the limits used when none are configured
+func DefaultLimits() *Limits {
+	return &Limits{
+		MinSize:        10,
+		MaxSize:        1000,
+		InitialSize:    50,
+		Step:           10,
+		MinConcurrency: 1,
+		MaxConcurrency: 8,
+		TargetLatency:  2 * time.Second,
+		MaxErrorRate:   0.05,
+		Backoff:        0.5,
+	}
+}
+
+// LoadLimits loads limits from environment variables named with the given
+// prefix, e.g. LoadLimits("PROJECTION_REBUILD") reads
+// PROJECTION_REBUILD_BATCH_MAX_SIZE
+func LoadLimits(prefix string) *Limits {
+	return LoadLimitsFrom(prefix, DefaultLimits())
+}
+
+// LoadLimitsFrom loads limits like LoadLimits, with other defaults for
+// variables that are unset
+func LoadLimitsFrom(prefix string, defaults *Limits) *Limits {
+	key := func(name string) string { return prefix + "_BATCH_" + name }
+
+	return &Limits{
+		MinSize:        config.Int(key("MIN_SIZE"), defaults.MinSize),
+		MaxSize:        config.Int(key("MAX_SIZE"), defaults.MaxSize),
+		InitialSize:    config.Int(key("INITIAL_SIZE"), defaults.InitialSize),
+		Step:           config.Int(key("STEP"), defaults.Step),
+		MinConcurrency: config.Int(key("MIN_CONCURRENCY"), defaults.MinConcurrency),
+		MaxConcurrency: config.Int(key("MAX_CONCURRENCY"), defaults.MaxConcurrency),
+		TargetLatency:  time.Duration(config.Int(key("TARGET_LATENCY_MS"), int(defaults.TargetLatency/time.Millisecond))) * time.Millisecond,
+		MaxErrorRate:   config.Float(key("MAX_ERROR_RATE"), defaults.MaxErrorRate),
+		Backoff:        config.Float(key("BACKOFF"), defaults.Backoff),
+	}
+}
+
+// Settings describes the variables LoadLimits reads for a prefix, so they
+// are validated at startup with the rest of the configuration
+func Settings(prefix string) []config.Setting {
+	var settings []config.Setting
+	for _, name := range []string{"MIN_SIZE", "MAX_SIZE", "INITIAL_SIZE", "STEP", "MIN_CONCURRENCY", "MAX_CONCURRENCY", "TARGET_LATENCY_MS"} {
+		settings = append(settings, config.Setting{Name: prefix + "_BATCH_" + name, Kind: config.KindInt})
+	}
+	for _, name := range []string{"MAX_ERROR_RATE", "BACKOFF"} {
+		settings = append(settings, config.Setting{Name: prefix + "_BATCH_" + name, Kind: config.KindFloat})
+	}
+	return settings
+}
+
+// normalize fixes limits that cannot be satisfied so a misconfiguration
+// degrades to fixed batches instead of failing
+func (l *Limits) normalize() {
+	if l.MinSize < 1 {
+		l.MinSize = 1
+	}
+	if l.MaxSize < l.MinSize {
+		l.MaxSize = l.MinSize
+	}
+	if l.MinConcurrency < 1 {
+		l.MinConcurrency = 1
+	}
+	if l.MaxConcurrency < l.MinConcurrency {
+		l.MaxConcurrency = l.MinConcurrency
+	}
+	if l.Step < 1 {
+		l.Step = 1
+	}
+	if l.Backoff <= 0 || l.Backoff >= 1 {
+		l.Backoff = 0.5
+	}
+	l.InitialSize = clamp(l.InitialSize, l.MinSize, l.MaxSize)
+}
+
+// Result describes one processed batch
+type Result struct {
+	Items    int
+	Failures int
+	Latency  time.Duration
+}
+
+// Stats reports a controller's current settings and how it got there
+type Stats struct {
+	Size          int           `json:"size"`
+	Concurrency   int           `json:"concurrency"`
+	Batches       int64         `json:"batches"`
+	Increases     int64         `json:"increases"`
+	Decreases     int64         `json:"decreases"`
+	LastLatency   time.Duration `json:"last_latency"`
+	LastErrorRate float64       `json:"last_error_rate"`
+}
+
+// Controller adapts batch size and concurrency to observed latency and
+// error rates. It is safe for concurrent use.
+type Controller struct {
+	mu     sync.Mutex
+	limits Limits
+	stats  Stats
+}
+
+// NewController creates a controller starting at the initial size and the
+// minimum concurrency
+func NewController(limits *Limits) *Controller {
+	if limits == nil {
+		limits = DefaultLimits()
+	}
+	normalized := *limits
+	normalized.normalize()
+
+	return &Controller{
+		limits: normalized,
+		stats: Stats{
+			Size:        normalized.InitialSize,
+			Concurrency: normalized.MinConcurrency,
+		},
+	}
+}
+
+// Size returns the number of items the next batch should hold
+func (c *Controller) Size() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats.Size
+}
+
+// Concurrency returns how many batches may run at once
+func (c *Controller) Concurrency() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats.Concurrency
+}
+
+// Observe adapts the controller to a finished batch. A batch that ran over
+// the target latency or error rate halves size and concurrency (by
+// Backoff); otherwise both grow by one step.
+func (c *Controller) Observe(result Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var errorRate float64
+	if result.Items > 0 {
+		errorRate = float64(result.Failures) / float64(result.Items)
+	}
+
+	c.stats.Batches++
+	c.stats.LastLatency = result.Latency
+	c.stats.LastErrorRate = errorRate
+
+	if result.Latency > c.limits.TargetLatency || errorRate > c.limits.MaxErrorRate {
+		c.stats.Size = clamp(int(float64(c.stats.Size)*c.limits.Backoff), c.limits.MinSize, c.limits.MaxSize)
+		c.stats.Concurrency = clamp(int(float64(c.stats.Concurrency)*c.limits.Backoff), c.limits.MinConcurrency, c.limits.MaxConcurrency)
+		c.stats.Decreases++
+		return
+	}
+
+	// Only full batches show the current size is sustainable
+	if result.Items < c.stats.Size {
+		return
+	}
+	c.stats.Size = clamp(c.stats.Size+c.limits.Step, c.limits.MinSize, c.limits.MaxSize)
+	c.stats.Concurrency = clamp(c.stats.Concurrency+1, c.limits.MinConcurrency, c.limits.MaxConcurrency)
+	c.stats.Increases++
+}
+
+// Stats returns the controller's current settings
+func (c *Controller) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// clamp bounds value to [lo, hi]
+func clamp(value, lo, hi int) int {
+	if value < lo {
+		return lo
+	}
+	if value > hi {
+		return hi
+	}
+	return value
+}
@@ -0,0 +1,69 @@
+package batching
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Handler processes one batch, returning how many of its items failed.
+// Failed items count toward the error rate; a returned error stops
+// processing.
+type Handler[T any] func(ctx context.Context, batch []T) (failed int, err error)
+
+// Process runs items through handler in batches sized by the controller,
+// running up to its concurrency of batches at a time and adapting after
+// each. It returns the number of items in batches that completed and the
+// first error, after which no new batches start.
+func Process[T any](ctx context.Context, controller *Controller, items []T, handler Handler[T]) (int, error) {
+	processed := 0
+
+	for offset := 0; offset < len(items); {
+		if err := ctx.Err(); err != nil {
+			return processed, err
+		}
+
+		// Cut one round of batches at the current size and concurrency
+		size, concurrency := controller.Size(), controller.Concurrency()
+		var round [][]T
+		for i := 0; i < concurrency && offset < len(items); i++ {
+			end := min(offset+size, len(items))
+			round = append(round, items[offset:end])
+			offset = end
+		}
+
+		errs := make([]error, len(round))
+		var wg sync.WaitGroup
+		for i, batch := range round {
+			wg.Add(1)
+			go func(i int, batch []T) {
+				defer wg.Done()
+
+				start := time.Now()
+				failed, err := handler(ctx, batch)
+				if err != nil {
+					failed = len(batch)
+				}
+				controller.Observe(Result{Items: len(batch), Failures: failed, Latency: time.Since(start)})
+				errs[i] = err
+			}(i, batch)
+		}
+		wg.Wait()
+
+		var firstErr error
+		for i, err := range errs {
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			processed += len(round[i])
+		}
+		if firstErr != nil {
+			return processed, firstErr
+		}
+	}
+
+	return processed, nil
+}
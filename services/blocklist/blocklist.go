@@ -0,0 +1,129 @@
+package blocklist
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Types of values that can be blocked
+const (
+	TypeEmail           = "email"
+	TypeCardFingerprint = "card_fingerprint"
+)
+
+// Sources an entry can come from
+const (
+	SourceAPI      = "api"      // Added through our API
+	SourceProvider = "provider" // Imported from the provider's value list
+)
+
+var (
+	// ErrBlocked is returned when a value is on the blocklist
+	ErrBlocked = errors.New("blocked by fraud rules")
+	// ErrEntryNotFound is returned for unknown blocklist entries
+	ErrEntryNotFound = errors.New("blocklist entry not found")
+	// ErrInvalidEntry is returned for entries with an unknown type or no value
+	ErrInvalidEntry = errors.New("invalid blocklist entry")
+)
+
+// Config controls how the blocklist is mirrored to the provider
+type Config struct {
+	SyncEnabled  bool
+	SyncInterval time.Duration
+
+	// Provider value list aliases, by entry type
+	Lists map[string]string
+}
+
+// LoadConfig loads the blocklist configuration from environment variables
+func LoadConfig() *Config {
+	config := &Config{
+		SyncEnabled:  true,
+		SyncInterval: 15 * time.Minute,
+		Lists: map[string]string{
+			TypeEmail:           "blocked_emails",
+			TypeCardFingerprint: "blocked_card_fingerprints",
+		},
+	}
+
+	if enabled, err := strconv.ParseBool(os.Getenv("BLOCKLIST_SYNC_ENABLED")); err == nil {
+		config.SyncEnabled = enabled
+	}
+	if minutes, err := strconv.Atoi(os.Getenv("BLOCKLIST_SYNC_INTERVAL_MINUTES")); err == nil && minutes > 0 {
+		config.SyncInterval = time.Duration(minutes) * time.Minute
+	}
+	if alias := os.Getenv("BLOCKLIST_RADAR_EMAIL_LIST"); alias != "" {
+		config.Lists[TypeEmail] = alias
+	}
+	if alias := os.Getenv("BLOCKLIST_RADAR_CARD_LIST"); alias != "" {
+		config.Lists[TypeCardFingerprint] = alias
+	}
+
+	return config
+}
+
+// Entry is a blocked email or card fingerprint
+type Entry struct {
+	ID             string    `json:"id"`
+	Type           string    `json:"type"`
+	Value          string    `json:"value"`
+	Reason         string    `json:"reason,omitempty"`
+	Source         string    `json:"source"`
+	CreatedBy      string    `json:"created_by,omitempty"`
+	ProviderItemID string    `json:"provider_item_id,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// MirrorItem is a value held in the provider's copy of a blocklist
+type MirrorItem struct {
+	ID    string
+	Value string
+}
+
+// SyncResult summarizes a reconciliation with the provider
+type SyncResult struct {
+	Pushed   int `json:"pushed"`   // Local entries added at the provider
+	Imported int `json:"imported"` // Provider items added locally
+	Removed  int `json:"removed"`  // Local entries whose provider item was deleted
+	Linked   int `json:"linked"`   // Local entries matched to existing provider items
+}
+
+// Store persists blocklist entries
+type Store interface {
+	CreateBlocklistEntry(ctx context.Context, entry *Entry) (*Entry, error)
+	GetBlocklistEntry(ctx context.Context, id string) (*Entry, error)
+	GetBlocklistEntryByValue(ctx context.Context, entryType, value string) (*Entry, error)
+	ListBlocklistEntries(ctx context.Context, entryType string) ([]*Entry, error)
+	SetBlocklistEntryProviderItem(ctx context.Context, id, providerItemID string) error
+	DeleteBlocklistEntry(ctx context.Context, id string) (bool, error)
+}
+
+// Mirror is the provider's copy of the blocklist, one list per type
+type Mirror interface {
+	AddItem(ctx context.Context, entryType, value string) (string, error)
+	RemoveItem(ctx context.Context, itemID string) error
+	ListItems(ctx context.Context, entryType string) ([]*MirrorItem, error)
+}
+
+// IsType reports whether values of a type can be blocked
+func IsType(entryType string) bool {
+	switch entryType {
+	case TypeEmail, TypeCardFingerprint:
+		return true
+	}
+	return false
+}
+
+// Normalize returns the form a value is stored and matched in. Emails are
+// case-insensitive; fingerprints are matched exactly.
+func Normalize(entryType, value string) string {
+	value = strings.TrimSpace(value)
+	if entryType == TypeEmail {
+		value = strings.ToLower(value)
+	}
+	return value
+}
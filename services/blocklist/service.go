@@ -0,0 +1,312 @@
+package blocklist
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"apis/payments/services/stripe"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// CustomerLookup retrieves customers so charges can be checked by email
+type CustomerLookup interface {
+	GetCustomer(ctx context.Context, customerID string) (*stripe.Customer, error)
+}
+
+// Service maintains the blocklist, enforces it, and keeps it in sync with
+// the provider's value lists
+type Service struct {
+	store     Store
+	mirror    Mirror
+	customers CustomerLookup
+	config    *Config
+	tracer    trace.Tracer
+}
+
+// NewService creates a new blocklist service. A nil mirror keeps the
+// blocklist local.
+func NewService(store Store, mirror Mirror, customers CustomerLookup, config *Config) *Service {
+	return &Service{
+		store:     store,
+		mirror:    mirror,
+		customers: customers,
+		config:    config,
+		tracer:    otel.Tracer("payments.blocklist"),
+	}
+}
+
+// Add blocks a value and mirrors it to the provider. A failure to mirror is
+// logged and retried by the next sync, so the value is blocked locally
+// either way. Adding a blocked value returns its existing entry.
+func (s *Service) Add(ctx context.Context, entry *Entry) (*Entry, error) {
+	ctx, span := s.tracer.Start(ctx, "Add")
+	defer span.End()
+
+	if !IsType(entry.Type) {
+		return nil, fmt.Errorf("%w: unknown type %q", ErrInvalidEntry, entry.Type)
+	}
+	entry.Value = Normalize(entry.Type, entry.Value)
+	if entry.Value == "" {
+		return nil, fmt.Errorf("%w: value cannot be empty", ErrInvalidEntry)
+	}
+	entry.ID = fmt.Sprintf("blk_%s", uuid.New().String())
+	if entry.Source == "" {
+		entry.Source = SourceAPI
+	}
+
+	stored, err := s.store.CreateBlocklistEntry(ctx, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.mirror != nil && stored.ProviderItemID == "" {
+		if err := s.push(ctx, stored); err != nil {
+			log.Printf("Failed to mirror blocklist entry %s: %v", stored.ID, err)
+		}
+	}
+
+	return stored, nil
+}
+
+// Remove unblocks a value at the provider and locally. The local entry is
+// kept if the provider item cannot be removed, so the two don't diverge.
+func (s *Service) Remove(ctx context.Context, id string) error {
+	ctx, span := s.tracer.Start(ctx, "Remove")
+	defer span.End()
+
+	entry, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if s.mirror != nil && entry.ProviderItemID != "" {
+		if err := s.mirror.RemoveItem(ctx, entry.ProviderItemID); err != nil {
+			return fmt.Errorf("failed to remove mirrored blocklist entry: %w", err)
+		}
+	}
+
+	deleted, err := s.store.DeleteBlocklistEntry(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrEntryNotFound
+	}
+
+	return nil
+}
+
+// Get returns a blocklist entry
+func (s *Service) Get(ctx context.Context, id string) (*Entry, error) {
+	ctx, span := s.tracer.Start(ctx, "Get")
+	defer span.End()
+
+	entry, err := s.store.GetBlocklistEntry(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrEntryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// List returns blocklist entries, optionally of one type
+func (s *Service) List(ctx context.Context, entryType string) ([]*Entry, error) {
+	ctx, span := s.tracer.Start(ctx, "List")
+	defer span.End()
+
+	if entryType != "" && !IsType(entryType) {
+		return nil, fmt.Errorf("%w: unknown type %q", ErrInvalidEntry, entryType)
+	}
+
+	return s.store.ListBlocklistEntries(ctx, entryType)
+}
+
+// Check returns ErrBlocked if a value is on the blocklist
+func (s *Service) Check(ctx context.Context, entryType, value string) error {
+	ctx, span := s.tracer.Start(ctx, "Check")
+	defer span.End()
+
+	value = Normalize(entryType, value)
+	if value == "" {
+		return nil
+	}
+
+	entry, err := s.store.GetBlocklistEntryByValue(ctx, entryType, value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check blocklist: %w", err)
+	}
+
+	return fmt.Errorf("%w: %s %s", ErrBlocked, entry.Type, entry.ID)
+}
+
+// CheckCharge blocks charges for customers whose email is on the blocklist
+func (s *Service) CheckCharge(ctx context.Context, customerID string) error {
+	ctx, span := s.tracer.Start(ctx, "CheckCharge")
+	defer span.End()
+
+	if customerID == "" || s.customers == nil {
+		return nil
+	}
+
+	customer, err := s.customers.GetCustomer(ctx, customerID)
+	if err != nil {
+		return fmt.Errorf("failed to look up customer for blocklist: %w", err)
+	}
+
+	return s.Check(ctx, TypeEmail, customer.Email)
+}
+
+// Sync reconciles the blocklist with the provider's value lists. Local
+// entries missing at the provider are pushed, provider items missing locally
+// are imported, and entries whose provider item was deleted are removed.
+func (s *Service) Sync(ctx context.Context) (*SyncResult, error) {
+	ctx, span := s.tracer.Start(ctx, "Sync")
+	defer span.End()
+
+	result := &SyncResult{}
+	if s.mirror == nil {
+		return result, nil
+	}
+
+	for _, entryType := range []string{TypeEmail, TypeCardFingerprint} {
+		if err := s.syncType(ctx, entryType, result); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// syncType reconciles one type's entries with its provider value list
+func (s *Service) syncType(ctx context.Context, entryType string, result *SyncResult) error {
+	items, err := s.mirror.ListItems(ctx, entryType)
+	if err != nil {
+		return fmt.Errorf("failed to list mirrored %s entries: %w", entryType, err)
+	}
+	entries, err := s.store.ListBlocklistEntries(ctx, entryType)
+	if err != nil {
+		return err
+	}
+
+	itemsByID := make(map[string]*MirrorItem, len(items))
+	itemsByValue := make(map[string]*MirrorItem, len(items))
+	for _, item := range items {
+		itemsByID[item.ID] = item
+		itemsByValue[Normalize(entryType, item.Value)] = item
+	}
+
+	linked := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		if _, ok := itemsByID[entry.ProviderItemID]; ok {
+			linked[entry.ProviderItemID] = true
+			continue
+		}
+
+		// The value may have been re-added at the provider under a new item
+		if item, ok := itemsByValue[entry.Value]; ok {
+			if err := s.store.SetBlocklistEntryProviderItem(ctx, entry.ID, item.ID); err != nil {
+				return err
+			}
+			linked[item.ID] = true
+			result.Linked++
+			continue
+		}
+
+		if entry.ProviderItemID != "" {
+			// Unblocked at the provider since we last synced
+			if _, err := s.store.DeleteBlocklistEntry(ctx, entry.ID); err != nil {
+				return err
+			}
+			result.Removed++
+			continue
+		}
+
+		if err := s.push(ctx, entry); err != nil {
+			return err
+		}
+		linked[entry.ProviderItemID] = true
+		result.Pushed++
+	}
+
+	for _, item := range items {
+		if linked[item.ID] {
+			continue
+		}
+		_, err := s.store.CreateBlocklistEntry(ctx, &Entry{
+			ID:             fmt.Sprintf("blk_%s", uuid.New().String()),
+			Type:           entryType,
+			Value:          Normalize(entryType, item.Value),
+			Source:         SourceProvider,
+			ProviderItemID: item.ID,
+		})
+		if err != nil {
+			return err
+		}
+		result.Imported++
+	}
+
+	return nil
+}
+
+// push adds an entry to the provider and links it to the new item
+func (s *Service) push(ctx context.Context, entry *Entry) error {
+	itemID, err := s.mirror.AddItem(ctx, entry.Type, entry.Value)
+	if err != nil {
+		return err
+	}
+	if err := s.store.SetBlocklistEntryProviderItem(ctx, entry.ID, itemID); err != nil {
+		return err
+	}
+	entry.ProviderItemID = itemID
+	return nil
+}
+
+// Start syncs with the provider on the configured interval until the
+// returned stop function is called
+func (s *Service) Start() (stop func()) {
+	if !s.config.SyncEnabled || s.mirror == nil {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(s.config.SyncInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				result, err := s.Sync(context.Background())
+				if err != nil {
+					log.Printf("Blocklist sync failed: %v", err)
+				}
+				if result.Pushed+result.Imported+result.Removed > 0 {
+					log.Printf("Blocklist sync pushed %d, imported %d, removed %d entries", result.Pushed, result.Imported, result.Removed)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
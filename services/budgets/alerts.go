@@ -0,0 +1,29 @@
+package budgets
+
+import (
+	"fmt"
+	"time"
+
+	"apis/payments/services/alerts"
+)
+
+// Alert is raised when a tenant's monthly spend crosses a budget threshold
+type Alert struct {
+	TenantID     string    `json:"tenant_id"`
+	Period       string    `json:"period"`
+	Threshold    int       `json:"threshold"`
+	Currency     string    `json:"currency"`
+	Spent        int64     `json:"spent"`
+	MonthlyLimit int64     `json:"monthly_limit"`
+	HardStop     bool      `json:"hard_stop"`
+	RaisedAt     time.Time `json:"raised_at"`
+}
+
+// Alerter delivers budget threshold alerts
+type Alerter = alerts.Alerter[*Alert]
+
+// Messages describes the alert for the service log
+func (a *Alert) Messages() []string {
+	return []string{fmt.Sprintf("tenant %s reached %d%% of its %s budget (%d/%d %s, hard stop=%t)",
+		a.TenantID, a.Threshold, a.Period, a.Spent, a.MonthlyLimit, a.Currency, a.HardStop)}
+}
@@ -0,0 +1,101 @@
+package budgets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Errors returned by the budget service
+var (
+	ErrBudgetExceeded = errors.New("monthly processing budget exceeded; an administrator must raise the limit")
+	ErrBudgetNotFound = errors.New("no budget configured for tenant")
+	ErrInvalidBudget  = errors.New("invalid budget")
+	ErrNoExchangeRate = errors.New("no exchange rate to convert the charge to the budget currency")
+)
+
+// DefaultThresholds are the percentages of the monthly limit that raise alerts
+var DefaultThresholds = []int{50, 80, 100}
+
+// Budget caps a tenant's monthly processing volume. The limit is stated in
+// the base reporting currency's minor units. With HardStop set, charges that
+// would take spend past the limit are rejected until the limit is raised.
+type Budget struct {
+	TenantID     string    `json:"tenant_id"`
+	MonthlyLimit int64     `json:"monthly_limit"`
+	Thresholds   []int     `json:"thresholds"`
+	HardStop     bool      `json:"hard_stop"`
+	UpdatedBy    string    `json:"updated_by,omitempty"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Validate checks the limit and thresholds, defaulting and sorting thresholds
+func (b *Budget) Validate() error {
+	if b.TenantID == "" {
+		return fmt.Errorf("%w: tenant ID cannot be empty", ErrInvalidBudget)
+	}
+	if b.MonthlyLimit <= 0 {
+		return fmt.Errorf("%w: monthly limit must be positive", ErrInvalidBudget)
+	}
+
+	if len(b.Thresholds) == 0 {
+		b.Thresholds = append([]int(nil), DefaultThresholds...)
+	}
+	seen := make(map[int]bool)
+	for _, threshold := range b.Thresholds {
+		if threshold <= 0 || threshold > 1000 {
+			return fmt.Errorf("%w: threshold %d must be between 1 and 1000 percent", ErrInvalidBudget, threshold)
+		}
+		if seen[threshold] {
+			return fmt.Errorf("%w: duplicate threshold %d", ErrInvalidBudget, threshold)
+		}
+		seen[threshold] = true
+	}
+	sort.Ints(b.Thresholds)
+
+	return nil
+}
+
+// Spend is a tenant's processed volume within one calendar month
+type Spend struct {
+	TenantID    string `json:"tenant_id"`
+	Period      string `json:"period"`
+	Amount      int64  `json:"amount"`
+	ChargeCount int64  `json:"charge_count"`
+}
+
+// Status reports a tenant's spend for the current month against its budget
+type Status struct {
+	TenantID    string  `json:"tenant_id"`
+	Period      string  `json:"period"`
+	Currency    string  `json:"currency"`
+	Spent       int64   `json:"spent"`
+	ChargeCount int64   `json:"charge_count"`
+	Budget      *Budget `json:"budget,omitempty"`
+	PercentUsed float64 `json:"percent_used,omitempty"`
+	Blocked     bool    `json:"blocked"`
+}
+
+// Store persists budgets, monthly spend and the thresholds already alerted
+type Store interface {
+	GetTenantBudget(ctx context.Context, tenantID string) (*Budget, error)
+	UpsertTenantBudget(ctx context.Context, budget *Budget) (*Budget, error)
+	DeleteTenantBudget(ctx context.Context, tenantID string) (bool, error)
+	GetTenantSpend(ctx context.Context, tenantID, period string) (*Spend, error)
+	AddTenantSpend(ctx context.Context, tenantID, period string, amount int64) (*Spend, error)
+	// RecordBudgetAlert returns false if the threshold was already alerted this period
+	RecordBudgetAlert(ctx context.Context, tenantID, period string, threshold int) (bool, error)
+}
+
+// Converter states charge amounts in the base reporting currency
+type Converter interface {
+	BaseCurrency() string
+	ToBase(amount int64, currency string) (int64, bool)
+}
+
+// Period returns the calendar month (UTC) spend at the given time counts toward
+func Period(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
@@ -0,0 +1,224 @@
+package budgets
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Service enforces per-tenant monthly budgets and alerts as spend crosses
+// their thresholds
+type Service struct {
+	store     Store
+	converter Converter
+	alerter   Alerter
+	tracer    trace.Tracer
+}
+
+// NewService creates a new budget service
+func NewService(store Store, converter Converter, alerter Alerter) *Service {
+	return &Service{
+		store:     store,
+		converter: converter,
+		alerter:   alerter,
+		tracer:    otel.Tracer("payments.budgets"),
+	}
+}
+
+// Get returns a tenant's budget
+func (s *Service) Get(ctx context.Context, tenantID string) (*Budget, error) {
+	ctx, span := s.tracer.Start(ctx, "Get")
+	defer span.End()
+
+	budget, err := s.store.GetTenantBudget(ctx, tenantID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrBudgetNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return budget, nil
+}
+
+// Set creates or replaces a tenant's budget. Raising the limit above the
+// month's spend lifts a hard stop immediately.
+func (s *Service) Set(ctx context.Context, budget *Budget) (*Budget, error) {
+	ctx, span := s.tracer.Start(ctx, "Set")
+	defer span.End()
+
+	if err := budget.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.store.UpsertTenantBudget(ctx, budget)
+}
+
+// Delete removes a tenant's budget, leaving its spend unlimited
+func (s *Service) Delete(ctx context.Context, tenantID string) error {
+	ctx, span := s.tracer.Start(ctx, "Delete")
+	defer span.End()
+
+	deleted, err := s.store.DeleteTenantBudget(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrBudgetNotFound
+	}
+
+	return nil
+}
+
+// Status returns a tenant's spend for the current month against its budget.
+// Tenants without a budget still report their spend.
+func (s *Service) Status(ctx context.Context, tenantID string) (*Status, error) {
+	ctx, span := s.tracer.Start(ctx, "Status")
+	defer span.End()
+
+	period := Period(time.Now())
+	status := &Status{
+		TenantID: tenantID,
+		Period:   period,
+		Currency: s.converter.BaseCurrency(),
+	}
+
+	spend, err := s.currentSpend(ctx, tenantID, period)
+	if err != nil {
+		return nil, err
+	}
+	status.Spent = spend.Amount
+	status.ChargeCount = spend.ChargeCount
+
+	budget, err := s.Get(ctx, tenantID)
+	if errors.Is(err, ErrBudgetNotFound) {
+		return status, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	status.Budget = budget
+	status.PercentUsed = float64(spend.Amount) * 100 / float64(budget.MonthlyLimit)
+	status.Blocked = budget.HardStop && spend.Amount >= budget.MonthlyLimit
+
+	return status, nil
+}
+
+// CheckCharge returns ErrBudgetExceeded if the tenant's budget has a hard
+// stop and the charge would take this month's spend past the limit. The
+// check is made before the charge is created, so concurrent charges may
+// overshoot the limit by at most their combined amount.
+func (s *Service) CheckCharge(ctx context.Context, tenantID, currency string, amount int64) error {
+	ctx, span := s.tracer.Start(ctx, "CheckCharge")
+	defer span.End()
+
+	budget, err := s.Get(ctx, tenantID)
+	if errors.Is(err, ErrBudgetNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !budget.HardStop {
+		return nil
+	}
+
+	baseAmount, ok := s.converter.ToBase(amount, currency)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNoExchangeRate, currency)
+	}
+
+	spend, err := s.currentSpend(ctx, tenantID, Period(time.Now()))
+	if err != nil {
+		return err
+	}
+
+	if spend.Amount+baseAmount > budget.MonthlyLimit {
+		return ErrBudgetExceeded
+	}
+
+	return nil
+}
+
+// RecordCharge adds a successful charge to the tenant's monthly spend and
+// alerts once for each budget threshold the spend has reached
+func (s *Service) RecordCharge(ctx context.Context, tenantID, currency string, amount int64) error {
+	ctx, span := s.tracer.Start(ctx, "RecordCharge")
+	defer span.End()
+
+	baseAmount, ok := s.converter.ToBase(amount, currency)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNoExchangeRate, currency)
+	}
+
+	period := Period(time.Now())
+	spend, err := s.store.AddTenantSpend(ctx, tenantID, period, baseAmount)
+	if err != nil {
+		return err
+	}
+
+	budget, err := s.Get(ctx, tenantID)
+	if errors.Is(err, ErrBudgetNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.alertThresholds(ctx, budget, spend)
+}
+
+// alertThresholds raises an alert for every threshold the spend has reached
+// that has not already alerted this period. Thresholds are checked against
+// the total rather than the charge that crossed them, so lowering the limit
+// mid-month alerts on the next charge.
+func (s *Service) alertThresholds(ctx context.Context, budget *Budget, spend *Spend) error {
+	for _, threshold := range budget.Thresholds {
+		if spend.Amount*100 < budget.MonthlyLimit*int64(threshold) {
+			break
+		}
+
+		first, err := s.store.RecordBudgetAlert(ctx, budget.TenantID, spend.Period, threshold)
+		if err != nil {
+			return err
+		}
+		if !first {
+			continue
+		}
+
+		alert := &Alert{
+			TenantID:     budget.TenantID,
+			Period:       spend.Period,
+			Threshold:    threshold,
+			Currency:     s.converter.BaseCurrency(),
+			Spent:        spend.Amount,
+			MonthlyLimit: budget.MonthlyLimit,
+			HardStop:     budget.HardStop,
+			RaisedAt:     time.Now(),
+		}
+		if err := s.alerter.Alert(ctx, alert); err != nil {
+			log.Printf("Failed to deliver budget alert for tenant %s: %v", budget.TenantID, err)
+		}
+	}
+
+	return nil
+}
+
+// currentSpend returns the tenant's spend for a period, zero if none is recorded
+func (s *Service) currentSpend(ctx context.Context, tenantID, period string) (*Spend, error) {
+	spend, err := s.store.GetTenantSpend(ctx, tenantID, period)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &Spend{TenantID: tenantID, Period: period}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return spend, nil
+}
@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"apis/payments/services/config"
+)
+
+// Kinds of cached objects
+const (
+	KindCustomer = "customer"
+	KindCharge   = "charge"
+)
+
+// ErrMiss is returned by stores for keys they don't hold
+var ErrMiss = errors.New("cache miss")
+
+// Config controls caching of provider reads
+type Config struct {
+	Enabled     bool
+	CustomerTTL time.Duration
+	ChargeTTL   time.Duration
+}
+
+// LoadConfig loads the cache configuration from environment variables
+func LoadConfig() *Config {
+	return &Config{
+		Enabled:     os.Getenv("CACHE_ENABLED") == "true",
+		CustomerTTL: time.Duration(config.Int("CACHE_CUSTOMER_TTL_SECONDS", 300)) * time.Second,
+		ChargeTTL:   time.Duration(config.Int("CACHE_CHARGE_TTL_SECONDS", 60)) * time.Second,
+	}
+}
+
+// Store holds cached values until they expire
+type Store interface {
+	// Get returns a key's value, or ErrMiss
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// Stats counts the reads of one kind of object
+type Stats struct {
+	Kind   string `json:"kind"`
+	Hits   int64  `json:"hits"`
+	Misses int64  `json:"misses"`
+	// Errors counts reads and writes the store failed, which fall back to
+	// the provider
+	Errors        int64   `json:"errors"`
+	Invalidations int64   `json:"invalidations"`
+	HitRate       float64 `json:"hit_rate"`
+}
+
+// Metrics counts cache hits and misses per kind of object
+type Metrics struct {
+	mu    sync.Mutex
+	kinds map[string]*Stats
+}
+
+// NewMetrics creates empty metrics
+func NewMetrics() *Metrics {
+	return &Metrics{kinds: make(map[string]*Stats)}
+}
+
+// record applies a change to a kind's counts
+func (m *Metrics) record(kind string, apply func(stats *Stats)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats, ok := m.kinds[kind]
+	if !ok {
+		stats = &Stats{Kind: kind}
+		m.kinds[kind] = stats
+	}
+	apply(stats)
+}
+
+// Snapshot returns the counts of each kind read so far, by kind
+func (m *Metrics) Snapshot() []Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := make([]Stats, 0, len(m.kinds))
+	for _, stats := range m.kinds {
+		entry := *stats
+		if reads := entry.Hits + entry.Misses; reads > 0 {
+			entry.HitRate = float64(entry.Hits) / float64(reads)
+		}
+		snapshot = append(snapshot, entry)
+	}
+
+	sort.Slice(snapshot, func(i, j int) bool {
+		return snapshot[i].Kind < snapshot[j].Kind
+	})
+	return snapshot
+}
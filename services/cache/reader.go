@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"apis/payments/services/tenancy"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Reader reads objects of one kind through the cache, loading and caching
+// them on a miss. Objects are cached per tenant, since each tenant reads
+// from its own provider account. Without a store every read is loaded.
+// Store failures are counted and fall back to loading, so the cache never
+// fails a read.
+type Reader[T any] struct {
+	store   Store
+	metrics *Metrics
+	kind    string
+	ttl     time.Duration
+	load    func(ctx context.Context, id string) (*T, error)
+	tracer  trace.Tracer
+}
+
+// NewReader creates a reader caching objects of a kind for ttl. store may be
+// nil to disable caching.
+func NewReader[T any](store Store, metrics *Metrics, kind string, ttl time.Duration, load func(ctx context.Context, id string) (*T, error)) *Reader[T] {
+	return &Reader[T]{
+		store:   store,
+		metrics: metrics,
+		kind:    kind,
+		ttl:     ttl,
+		load:    load,
+		tracer:  otel.Tracer("payments.cache"),
+	}
+}
+
+// Get returns an object from the cache, or loads and caches it
+func (r *Reader[T]) Get(ctx context.Context, id string) (*T, error) {
+	if r.store == nil {
+		return r.load(ctx, id)
+	}
+
+	ctx, span := r.tracer.Start(ctx, "Get", trace.WithAttributes(attribute.String("cache.kind", r.kind)))
+	defer span.End()
+
+	data, err := r.store.Get(ctx, r.key(ctx, id))
+	if err == nil {
+		var value T
+		if err := json.Unmarshal(data, &value); err == nil {
+			span.SetAttributes(attribute.Bool("cache.hit", true))
+			r.metrics.record(r.kind, func(stats *Stats) { stats.Hits++ })
+			return &value, nil
+		}
+	} else if !errors.Is(err, ErrMiss) {
+		log.Printf("Failed to read cached %s %s: %v", r.kind, id, err)
+		r.metrics.record(r.kind, func(stats *Stats) { stats.Errors++ })
+	}
+
+	span.SetAttributes(attribute.Bool("cache.hit", false))
+	r.metrics.record(r.kind, func(stats *Stats) { stats.Misses++ })
+
+	value, err := r.load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err = json.Marshal(value)
+	if err == nil {
+		err = r.store.Set(ctx, r.key(ctx, id), data, r.ttl)
+	}
+	if err != nil {
+		log.Printf("Failed to cache %s %s: %v", r.kind, id, err)
+		r.metrics.record(r.kind, func(stats *Stats) { stats.Errors++ })
+	}
+
+	return value, nil
+}
+
+// Invalidate drops the context's tenant's objects from the cache, so the
+// next read loads them. Failures are logged; the objects then expire after
+// their TTL.
+func (r *Reader[T]) Invalidate(ctx context.Context, ids ...string) {
+	if r.store == nil || len(ids) == 0 {
+		return
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = r.key(ctx, id)
+	}
+
+	if err := r.store.Delete(ctx, keys...); err != nil {
+		log.Printf("Failed to invalidate cached %s %v: %v", r.kind, ids, err)
+		r.metrics.record(r.kind, func(stats *Stats) { stats.Errors++ })
+		return
+	}
+	r.metrics.record(r.kind, func(stats *Stats) { stats.Invalidations += int64(len(ids)) })
+}
+
+// key returns the store key of the context's tenant's object
+func (r *Reader[T]) key(ctx context.Context, id string) string {
+	return "cache:" + r.kind + ":" + tenancy.ID(ctx) + ":" + id
+}
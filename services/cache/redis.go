@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"apis/payments/services/redis"
+)
+
+// RedisStore keeps cached values in Redis, shared by every instance
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore creates a store over a Redis client
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+// Get returns a key's value, or ErrMiss
+func (s *RedisStore) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := s.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.ErrNil) {
+		return nil, ErrMiss
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cached value: %w", err)
+	}
+	return value, nil
+}
+
+// Set stores a value expiring after ttl
+func (s *RedisStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := s.client.Set(ctx, key, value, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to cache value: %w", err)
+	}
+	return nil
+}
+
+// Delete removes keys
+func (s *RedisStore) Delete(ctx context.Context, keys ...string) error {
+	if err := s.client.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("failed to delete cached values: %w", err)
+	}
+	return nil
+}
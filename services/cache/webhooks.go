@@ -0,0 +1,151 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+
+	"apis/payments/services/stripe"
+	"apis/payments/services/tenancy"
+
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// Invalidatable is a cache objects can be dropped from
+type Invalidatable interface {
+	Invalidate(ctx context.Context, ids ...string)
+}
+
+// ChargeTenants resolves the tenant of a locally stored charge
+type ChargeTenants interface {
+	GetChargeTenant(ctx context.Context, chargeID string) (string, error)
+}
+
+// customerEvents report changes to a customer
+var customerEvents = []stripego.EventType{
+	stripego.EventTypeCustomerUpdated,
+	stripego.EventTypeCustomerDeleted,
+}
+
+// chargeEvents report changes to a charge, its refunds or its disputes
+var chargeEvents = []stripego.EventType{
+	stripego.EventTypeChargeCaptured,
+	stripego.EventTypeChargeExpired,
+	stripego.EventTypeChargeFailed,
+	stripego.EventTypeChargePending,
+	stripego.EventTypeChargeRefunded,
+	stripego.EventTypeChargeSucceeded,
+	stripego.EventTypeChargeUpdated,
+	stripego.EventTypeChargeRefundUpdated,
+	stripego.EventTypeChargeDisputeCreated,
+	stripego.EventTypeChargeDisputeClosed,
+	stripego.EventTypeChargeDisputeFundsReinstated,
+	stripego.EventTypeChargeDisputeFundsWithdrawn,
+}
+
+// RegisterWebhookHandlers drops customers and charges from their caches
+// when the provider reports they changed, so changes made outside the API
+// are not served stale until they expire. Objects are dropped for the
+// tenant named in their metadata; refunds and disputes carry no tenant, so
+// their charge is dropped for the charge's tenant.
+func RegisterWebhookHandlers(webhooks *stripe.WebhookService, customers, charges Invalidatable, tenants ChargeTenants) {
+	for _, eventType := range customerEvents {
+		webhooks.On(eventType, func(ctx context.Context, event stripego.Event) error {
+			if id := eventObjectID(event); id != "" {
+				customers.Invalidate(tenancy.WithTenant(ctx, eventTenant(event)), id)
+			}
+			return nil
+		})
+	}
+
+	for _, eventType := range chargeEvents {
+		webhooks.On(eventType, func(ctx context.Context, event stripego.Event) error {
+			if id := eventChargeID(event); id != "" {
+				charges.Invalidate(tenancy.WithTenant(ctx, chargeTenant(ctx, event, id, tenants)), id)
+			}
+			return nil
+		})
+	}
+}
+
+// eventObject is the part of an event's object naming it, its charge and
+// its tenant
+type eventObject struct {
+	ID       string            `json:"id"`
+	Object   string            `json:"object"`
+	Charge   json.RawMessage   `json:"charge"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+// eventTenant returns the tenant in an event object's metadata, or the
+// default tenant
+func eventTenant(event stripego.Event) string {
+	var object eventObject
+	if err := json.Unmarshal(event.Data.Raw, &object); err != nil || object.Metadata["tenant_id"] == "" {
+		return tenancy.DefaultTenantID
+	}
+	return object.Metadata["tenant_id"]
+}
+
+// chargeTenant returns the tenant of the charge an event concerns: the
+// tenant in the metadata of the charge, which refund and dispute events
+// only carry when it is expanded, or of the locally stored charge. Charges
+// not stored locally belong to the default tenant.
+func chargeTenant(ctx context.Context, event stripego.Event, chargeID string, tenants ChargeTenants) string {
+	var object eventObject
+	if err := json.Unmarshal(event.Data.Raw, &object); err != nil {
+		return tenancy.DefaultTenantID
+	}
+	if object.Object == "charge" {
+		return eventTenant(event)
+	}
+
+	var charge eventObject
+	if err := json.Unmarshal(object.Charge, &charge); err == nil && charge.Metadata["tenant_id"] != "" {
+		return charge.Metadata["tenant_id"]
+	}
+
+	tenantID, err := tenants.GetChargeTenant(ctx, chargeID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Failed to look up tenant of charge %s: %v", chargeID, err)
+		}
+		return tenancy.DefaultTenantID
+	}
+	return tenantID
+}
+
+// eventObjectID returns the ID of an event's object
+func eventObjectID(event stripego.Event) string {
+	var object eventObject
+	if err := json.Unmarshal(event.Data.Raw, &object); err != nil {
+		return ""
+	}
+	return object.ID
+}
+
+// eventChargeID returns the charge an event concerns: the event's object,
+// or the charge of a refund or dispute, which may be expanded
+func eventChargeID(event stripego.Event) string {
+	var object eventObject
+	if err := json.Unmarshal(event.Data.Raw, &object); err != nil {
+		return ""
+	}
+	if object.Object == "charge" {
+		return object.ID
+	}
+
+	var chargeID string
+	if err := json.Unmarshal(object.Charge, &chargeID); err == nil {
+		return chargeID
+	}
+	var charge struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(object.Charge, &charge); err == nil {
+		return charge.ID
+	}
+	return ""
+}
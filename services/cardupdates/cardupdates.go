@@ -0,0 +1,113 @@
+package cardupdates
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"apis/payments/services/stripe"
+
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// Sources of a card update
+const (
+	SourceAccountUpdater = "account_updater" // The network refreshed an expired or reissued card
+	SourceUpdate         = "update"          // The card was edited through the API or Dashboard
+)
+
+// EventPaymentMethodUpdated is emitted when the details of a saved card change
+const EventPaymentMethodUpdated = "payments.payment_method.updated"
+
+// Update is a change to the details of a saved card
+type Update struct {
+	PaymentMethodID string       `json:"payment_method_id"`
+	CustomerID      string       `json:"customer_id"`
+	Source          string       `json:"source"`
+	Previous        *stripe.Card `json:"previous"`
+	Card            *stripe.Card `json:"card"`
+	// Changes names the card fields that changed, e.g. exp_year
+	Changes   []string  `json:"changes"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Retrier retries a customer's failed subscription payments
+type Retrier interface {
+	RetryCustomer(ctx context.Context, customerID string) (int, error)
+}
+
+// Tokens keeps the card fingerprint of vault tokens current
+type Tokens interface {
+	UpdateFingerprint(ctx context.Context, provider, providerToken, fingerprint string) error
+}
+
+// ParseUpdate reads the card update a payment_method.updated or
+// payment_method.automatically_updated event describes. Events that changed
+// no card details, or concern payment methods not saved to a customer,
+// return nil.
+func ParseUpdate(event stripego.Event) (*Update, error) {
+	var stripePaymentMethod stripego.PaymentMethod
+	if err := json.Unmarshal(event.Data.Raw, &stripePaymentMethod); err != nil {
+		return nil, fmt.Errorf("failed to parse payment method: %w", err)
+	}
+
+	paymentMethod := stripe.ConvertPaymentMethod(&stripePaymentMethod)
+	if paymentMethod.Card == nil || paymentMethod.Customer == "" {
+		return nil, nil
+	}
+	previousCard, ok := event.Data.PreviousAttributes["card"]
+	if !ok {
+		return nil, nil
+	}
+
+	// Previous attributes only hold the fields that changed
+	previous := *paymentMethod.Card
+	raw, err := json.Marshal(previousCard)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read previous card: %w", err)
+	}
+	if err := json.Unmarshal(raw, &previous); err != nil {
+		return nil, fmt.Errorf("failed to parse previous card: %w", err)
+	}
+
+	update := &Update{
+		PaymentMethodID: paymentMethod.ID,
+		CustomerID:      paymentMethod.Customer,
+		Source:          SourceUpdate,
+		Previous:        &previous,
+		Card:            paymentMethod.Card,
+		Changes:         changedFields(&previous, paymentMethod.Card),
+		UpdatedAt:       time.Unix(event.Created, 0).UTC(),
+	}
+	if event.Type == stripego.EventTypePaymentMethodAutomaticallyUpdated {
+		update.Source = SourceAccountUpdater
+	}
+	if len(update.Changes) == 0 {
+		return nil, nil
+	}
+
+	return update, nil
+}
+
+// changedFields names the card fields that differ between two cards
+func changedFields(previous, current *stripe.Card) []string {
+	fields := []struct {
+		name    string
+		changed bool
+	}{
+		{"brand", previous.Brand != current.Brand},
+		{"last4", previous.Last4 != current.Last4},
+		{"exp_month", previous.ExpMonth != current.ExpMonth},
+		{"exp_year", previous.ExpYear != current.ExpYear},
+		{"fingerprint", previous.Fingerprint != current.Fingerprint},
+	}
+
+	changes := []string{}
+	for _, field := range fields {
+		if field.changed {
+			changes = append(changes, field.name)
+		}
+	}
+	return changes
+}
@@ -0,0 +1,81 @@
+package cardupdates
+
+import (
+	"context"
+	"log"
+
+	"apis/payments/services/events"
+	"apis/payments/services/stripe"
+
+	stripego "github.com/stripe/stripe-go/v76"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// provider holds the cards updates are reported for, as named in the vault
+const provider = "stripe"
+
+// Service follows changes to saved cards, whether refreshed by the card
+// account updater or edited, so subscription billing picks up the new card.
+// The card details themselves are mirrored by the mirror service.
+type Service struct {
+	retrier Retrier
+	tokens  Tokens
+	emitter *events.Emitter
+	tracer  trace.Tracer
+}
+
+// NewService creates a new card update service
+func NewService(retrier Retrier, tokens Tokens, emitter *events.Emitter) *Service {
+	return &Service{
+		retrier: retrier,
+		tokens:  tokens,
+		emitter: emitter,
+		tracer:  otel.Tracer("payments.cardupdates"),
+	}
+}
+
+// Apply follows a card update: the vault token takes the card's new
+// fingerprint, a payment_method.updated event is emitted, and the
+// customer's failed subscription payments are retried on the new card.
+// Failing to retry is logged, since retries also run on schedule.
+func (s *Service) Apply(ctx context.Context, update *Update) error {
+	ctx, span := s.tracer.Start(ctx, "Apply")
+	defer span.End()
+
+	if update.Previous.Fingerprint != update.Card.Fingerprint {
+		if err := s.tokens.UpdateFingerprint(ctx, provider, update.PaymentMethodID, update.Card.Fingerprint); err != nil {
+			return err
+		}
+	}
+
+	if err := s.emitter.Emit(ctx, "payment_methods", EventPaymentMethodUpdated, update.PaymentMethodID, update); err != nil {
+		log.Printf("Failed to emit %s for payment method %s: %v", EventPaymentMethodUpdated, update.PaymentMethodID, err)
+	}
+
+	retried, err := s.retrier.RetryCustomer(ctx, update.CustomerID)
+	if err != nil {
+		log.Printf("Failed to retry payments of customer %s after card update: %v", update.CustomerID, err)
+	} else if retried > 0 {
+		log.Printf("Retried %d failed payments of customer %s after card update", retried, update.CustomerID)
+	}
+
+	return nil
+}
+
+// RegisterWebhookHandlers applies the card updates reported by Stripe
+func (s *Service) RegisterWebhookHandlers(webhooks *stripe.WebhookService) {
+	for _, eventType := range []stripego.EventType{
+		stripego.EventTypePaymentMethodUpdated,
+		stripego.EventTypePaymentMethodAutomaticallyUpdated,
+	} {
+		webhooks.On(eventType, func(ctx context.Context, event stripego.Event) error {
+			update, err := ParseUpdate(event)
+			if err != nil || update == nil {
+				return err
+			}
+
+			return s.Apply(ctx, update)
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package chargesearch
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"apis/payments/services/stripe"
+)
+
+// ExportPageSize is the number of charges read from the store at a time
+// while exporting
+const ExportPageSize = 500
+
+// Export formats
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// ContentTypes maps each export format to the content type it is served as
+var ContentTypes = map[string]string{
+	FormatCSV:  "text/csv; charset=utf-8",
+	FormatJSON: "application/json",
+}
+
+// ErrUnsupportedFormat is returned when exporting to a format other than csv or json
+var ErrUnsupportedFormat = errors.New("format must be csv or json")
+
+// csvHeader names the columns of a CSV export
+var csvHeader = []string{
+	"id", "created", "amount", "amount_refunded", "currency", "status", "captured", "refunded",
+	"disputed", "customer_id", "payment_method_id", "invoice_id", "description", "metadata",
+}
+
+// flusher is a writer buffering output, such as a response body stream
+type flusher interface {
+	Flush() error
+}
+
+// Export writes every charge matching a filter to w, newest first, as CSV
+// with a header row or as a JSON array. Charges are read a page at a time
+// and w is flushed after each, so exports of any size stream in constant
+// memory. The filter's cursor and limit are ignored.
+func (s *Service) Export(ctx context.Context, filter Filter, format string, w io.Writer) error {
+	ctx, span := s.tracer.Start(ctx, "Export")
+	defer span.End()
+
+	if _, ok := ContentTypes[format]; !ok {
+		return ErrUnsupportedFormat
+	}
+	if err := filter.Validate(); err != nil {
+		return err
+	}
+
+	var encoder exportEncoder = &jsonExporter{w: w}
+	if format == FormatCSV {
+		encoder = &csvExporter{w: csv.NewWriter(w)}
+	}
+	if err := encoder.Begin(); err != nil {
+		return err
+	}
+
+	filter = normalize(filter)
+	filter.StartingAfter = ""
+	filter.Limit = ExportPageSize
+	for {
+		charges, err := s.store.SearchCharges(ctx, filter)
+		if err != nil {
+			return err
+		}
+		for _, charge := range charges {
+			if err := encoder.Encode(charge); err != nil {
+				return fmt.Errorf("failed to write charge %s: %w", charge.ID, err)
+			}
+		}
+		if err := encoder.Flush(); err != nil {
+			return err
+		}
+		if f, ok := w.(flusher); ok {
+			if err := f.Flush(); err != nil {
+				return fmt.Errorf("failed to flush export: %w", err)
+			}
+		}
+
+		if len(charges) < ExportPageSize {
+			return encoder.End()
+		}
+		filter.StartingAfter = charges[len(charges)-1].ID
+	}
+}
+
+// exportEncoder writes charges in an export format
+type exportEncoder interface {
+	Begin() error
+	Encode(charge *stripe.Charge) error
+	Flush() error
+	End() error
+}
+
+// csvExporter writes a header row and a row per charge
+type csvExporter struct {
+	w *csv.Writer
+}
+
+func (e *csvExporter) Begin() error {
+	return e.w.Write(csvHeader)
+}
+
+func (e *csvExporter) Encode(charge *stripe.Charge) error {
+	metadata := ""
+	if len(charge.Metadata) > 0 {
+		encoded, err := json.Marshal(charge.Metadata)
+		if err != nil {
+			return err
+		}
+		metadata = string(encoded)
+	}
+
+	return e.w.Write([]string{
+		charge.ID,
+		time.Unix(charge.Created, 0).UTC().Format(time.RFC3339),
+		strconv.FormatInt(charge.Amount, 10),
+		strconv.FormatInt(charge.AmountRefunded, 10),
+		charge.Currency,
+		charge.Status,
+		strconv.FormatBool(charge.Captured),
+		strconv.FormatBool(charge.Refunded),
+		strconv.FormatBool(charge.Disputed),
+		charge.CustomerID,
+		charge.PaymentMethodID,
+		charge.InvoiceID,
+		csvText(charge.Description),
+		metadata,
+	})
+}
+
+// csvText keeps free text from being read as a formula by spreadsheets
+func csvText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func (e *csvExporter) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvExporter) End() error {
+	return e.Flush()
+}
+
+// jsonExporter writes a JSON array of charges, one per line
+type jsonExporter struct {
+	w     io.Writer
+	count int
+}
+
+func (e *jsonExporter) Begin() error {
+	_, err := io.WriteString(e.w, "[")
+	return err
+}
+
+func (e *jsonExporter) Encode(charge *stripe.Charge) error {
+	encoded, err := json.Marshal(charge)
+	if err != nil {
+		return err
+	}
+
+	separator := "\n"
+	if e.count > 0 {
+		separator = ",\n"
+	}
+	e.count++
+	_, err = io.WriteString(e.w, separator+string(encoded))
+	return err
+}
+
+func (e *jsonExporter) Flush() error {
+	return nil
+}
+
+func (e *jsonExporter) End() error {
+	_, err := io.WriteString(e.w, "\n]\n")
+	return err
+}
@@ -0,0 +1,96 @@
+package chargesearch
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"apis/payments/services/stripe"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	// ErrInvalidCreatedRange is returned when created_after is not before created_before
+	ErrInvalidCreatedRange = errors.New("created_after must be before created_before")
+	// ErrInvalidAmountRange is returned for negative amounts or a min_amount above max_amount
+	ErrInvalidAmountRange = errors.New("min_amount and max_amount must be positive, with min_amount at most max_amount")
+	// ErrInvalidCardLast4 is returned when card_last4 is not four digits
+	ErrInvalidCardLast4 = errors.New("card_last4 must be four digits")
+)
+
+// Filter narrows a charge search. Every set field must match; Metadata
+// matches charges holding every pair. Brand and Last4 match the card of the
+// charge's payment method.
+type Filter struct {
+	TenantID      string
+	Status        string
+	Currency      string
+	CustomerID    string
+	MinAmount     *int64 // Inclusive, in the smallest currency unit
+	MaxAmount     *int64 // Inclusive
+	CardBrand     string
+	CardLast4     string
+	Metadata      map[string]string
+	CreatedAfter  *time.Time // Inclusive
+	CreatedBefore *time.Time // Exclusive
+	StartingAfter string     // ID of the last charge of the previous page
+	Limit         int
+}
+
+// Validate checks a filter's ranges and card digits
+func (f *Filter) Validate() error {
+	if (f.MinAmount != nil && *f.MinAmount < 0) || (f.MaxAmount != nil && *f.MaxAmount < 0) ||
+		(f.MinAmount != nil && f.MaxAmount != nil && *f.MinAmount > *f.MaxAmount) {
+		return ErrInvalidAmountRange
+	}
+	if f.CreatedAfter != nil && f.CreatedBefore != nil && !f.CreatedAfter.Before(*f.CreatedBefore) {
+		return ErrInvalidCreatedRange
+	}
+	if f.CardLast4 != "" && (len(f.CardLast4) != 4 || strings.Trim(f.CardLast4, "0123456789") != "") {
+		return ErrInvalidCardLast4
+	}
+	return nil
+}
+
+// Store searches the charges mirrored from the provider, newest first.
+// Currencies and card brands are passed lowercased.
+type Store interface {
+	SearchCharges(ctx context.Context, filter Filter) ([]*stripe.Charge, error)
+}
+
+// Service searches and exports a tenant's charges
+type Service struct {
+	store  Store
+	tracer trace.Tracer
+}
+
+// NewService creates a new charge search service
+func NewService(store Store) *Service {
+	return &Service{
+		store:  store,
+		tracer: otel.Tracer("payments.chargesearch"),
+	}
+}
+
+// Search returns a page of a tenant's charges matching a filter, newest first
+func (s *Service) Search(ctx context.Context, filter Filter) ([]*stripe.Charge, error) {
+	ctx, span := s.tracer.Start(ctx, "Search")
+	defer span.End()
+
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.store.SearchCharges(ctx, normalize(filter))
+}
+
+// normalize lowercases the filter values the store compares lowercased
+func normalize(filter Filter) Filter {
+	filter.Status = strings.TrimSpace(filter.Status)
+	filter.Currency = strings.ToLower(strings.TrimSpace(filter.Currency))
+	filter.CardBrand = strings.ToLower(strings.TrimSpace(filter.CardBrand))
+	return filter
+}
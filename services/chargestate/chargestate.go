@@ -0,0 +1,137 @@
+package chargestate
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"apis/payments/services/stripe"
+)
+
+// Charge states
+const (
+	StateCreated           = "created"
+	StateAuthorized        = "authorized"
+	StateCaptured          = "captured"
+	StatePartiallyRefunded = "partially_refunded"
+	StateRefunded          = "refunded"
+	StateDisputed          = "disputed"
+	StateVoided            = "voided"
+	StateFailed            = "failed"
+)
+
+// Sources a transition can come from
+const (
+	SourceAPI     = "api"
+	SourceWebhook = "webhook"
+	SourceManual  = "manual" // Set by an operator
+)
+
+// EventTransitioned is emitted for every recorded transition
+const EventTransitioned = "payments.charge.transitioned"
+
+var (
+	// ErrIllegalTransition is returned for a transition the state machine does not allow
+	ErrIllegalTransition = errors.New("illegal charge state transition")
+	// ErrConcurrentTransition is returned when another transition was recorded first
+	ErrConcurrentTransition = errors.New("charge state changed concurrently")
+	// ErrUnknownState is returned for states outside the state machine
+	ErrUnknownState = errors.New("unknown charge state")
+	// ErrNoState is returned for charges with no recorded transitions
+	ErrNoState = errors.New("charge has no recorded state")
+)
+
+// transitions lists the states each state may move to. A won dispute
+// returns the charge to captured or partially_refunded; a lost one stays
+// disputed.
+var transitions = map[string][]string{
+	StateCreated:           {StateAuthorized, StateCaptured, StateFailed},
+	StateAuthorized:        {StateCaptured, StateVoided, StateFailed},
+	StateCaptured:          {StatePartiallyRefunded, StateRefunded, StateDisputed},
+	StatePartiallyRefunded: {StateRefunded, StateDisputed},
+	StateDisputed:          {StateCaptured, StatePartiallyRefunded},
+	StateRefunded:          {},
+	StateVoided:            {},
+	StateFailed:            {},
+}
+
+// Transition is one recorded change of a charge's state
+type Transition struct {
+	ID        string    `json:"id"`
+	ChargeID  string    `json:"charge_id"`
+	Sequence  int       `json:"sequence"`
+	From      string    `json:"from,omitempty"`
+	To        string    `json:"to"`
+	Source    string    `json:"source"`
+	EventID   string    `json:"event_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Store persists the transition log. AppendChargeTransition returns
+// sql.ErrNoRows when a transition with the same sequence already exists.
+type Store interface {
+	AppendChargeTransition(ctx context.Context, transition *Transition) (*Transition, error)
+	GetLatestChargeTransition(ctx context.Context, chargeID string) (*Transition, error)
+	ListChargeTransitions(ctx context.Context, chargeID string) ([]*Transition, error)
+}
+
+// IsState reports whether a state is part of the state machine
+func IsState(state string) bool {
+	_, ok := transitions[state]
+	return ok
+}
+
+// CanTransition reports whether a charge may move from one state to another
+func CanTransition(from, to string) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsFinal reports whether no transitions leave a state
+func IsFinal(state string) bool {
+	next, ok := transitions[state]
+	return ok && len(next) == 0
+}
+
+// EnteredAt returns when a charge last entered each state in its
+// transitions
+func EnteredAt(transitions []*Transition) map[string]time.Time {
+	entered := make(map[string]time.Time, len(transitions))
+	for _, transition := range transitions {
+		entered[transition.To] = transition.CreatedAt
+	}
+	return entered
+}
+
+// Derive returns the state a provider charge's payment is in. Disputes are
+// not derived: a charge stays flagged as disputed at the provider after the
+// dispute closes, so disputed is entered and left on dispute events only.
+func Derive(charge *stripe.Charge) string {
+	switch charge.Status {
+	case "failed":
+		return StateFailed
+	case "pending":
+		return StateCreated
+	}
+
+	if !charge.Captured {
+		// Refunding an uncaptured charge releases the authorization
+		if charge.Refunded {
+			return StateVoided
+		}
+		return StateAuthorized
+	}
+
+	switch {
+	case charge.Refunded || (charge.Amount > 0 && charge.AmountRefunded >= charge.Amount):
+		return StateRefunded
+	case charge.AmountRefunded > 0:
+		return StatePartiallyRefunded
+	default:
+		return StateCaptured
+	}
+}
@@ -0,0 +1,235 @@
+package chargestate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"apis/payments/services/events"
+	"apis/payments/services/stripe"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// fillOrder is the order intermediate states are tried in when a provider
+// snapshot shows a charge past a state whose event was missed
+var fillOrder = []string{StateAuthorized, StateCaptured}
+
+// Service records charge state transitions, rejecting those the state
+// machine does not allow
+type Service struct {
+	store   Store
+	emitter *events.Emitter
+	tracer  trace.Tracer
+}
+
+// NewService creates a new charge state service
+func NewService(store Store, emitter *events.Emitter) *Service {
+	return &Service{
+		store:   store,
+		emitter: emitter,
+		tracer:  otel.Tracer("payments.chargestate"),
+	}
+}
+
+// State returns a charge's current state
+func (s *Service) State(ctx context.Context, chargeID string) (string, error) {
+	ctx, span := s.tracer.Start(ctx, "State")
+	defer span.End()
+
+	latest, err := s.latest(ctx, chargeID)
+	if err != nil {
+		return "", err
+	}
+	if latest == nil {
+		return "", ErrNoState
+	}
+
+	return latest.To, nil
+}
+
+// History returns a charge's transitions, oldest first
+func (s *Service) History(ctx context.Context, chargeID string) ([]*Transition, error) {
+	ctx, span := s.tracer.Start(ctx, "History")
+	defer span.End()
+
+	if chargeID == "" {
+		return nil, fmt.Errorf("charge ID cannot be empty")
+	}
+
+	return s.store.ListChargeTransitions(ctx, chargeID)
+}
+
+// Transition moves a charge to a state. Moving a charge to the state it is
+// already in records nothing and returns its latest transition, so
+// redelivered updates are harmless.
+func (s *Service) Transition(ctx context.Context, chargeID, to, source, eventID string) (*Transition, error) {
+	ctx, span := s.tracer.Start(ctx, "Transition")
+	defer span.End()
+
+	return s.advance(ctx, chargeID, to, source, eventID, false)
+}
+
+// Apply moves a charge to the state a provider snapshot of it shows. Unlike
+// Transition, states the snapshot proves the charge passed through are
+// recorded first, so a missed or out-of-order event doesn't leave the charge
+// stuck behind the provider.
+func (s *Service) Apply(ctx context.Context, charge *stripe.Charge, source, eventID string) (*Transition, error) {
+	ctx, span := s.tracer.Start(ctx, "Apply")
+	defer span.End()
+
+	// Charge events don't end a dispute; only the dispute closing does
+	if charge.Disputed {
+		current, err := s.latest(ctx, charge.ID)
+		if err != nil {
+			return nil, err
+		}
+		if current != nil && current.To == StateDisputed {
+			return current, nil
+		}
+	}
+
+	return s.advance(ctx, charge.ID, Derive(charge), source, eventID, true)
+}
+
+// Dispute moves a charge into dispute
+func (s *Service) Dispute(ctx context.Context, chargeID, source, eventID string) (*Transition, error) {
+	ctx, span := s.tracer.Start(ctx, "Dispute")
+	defer span.End()
+
+	return s.advance(ctx, chargeID, StateDisputed, source, eventID, true)
+}
+
+// CloseDispute returns a charge whose dispute was won to the state its
+// payment is in. Lost disputes leave the charge disputed.
+func (s *Service) CloseDispute(ctx context.Context, charge *stripe.Charge, disputeStatus, source, eventID string) (*Transition, error) {
+	ctx, span := s.tracer.Start(ctx, "CloseDispute")
+	defer span.End()
+
+	if disputeStatus != "won" {
+		return nil, nil
+	}
+
+	return s.advance(ctx, charge.ID, Derive(charge), source, eventID, false)
+}
+
+// Allows returns ErrIllegalTransition unless a charge may move to one of the
+// given states. Charges with no recorded state are allowed, as they predate
+// the transition log.
+func (s *Service) Allows(ctx context.Context, chargeID string, to ...string) error {
+	ctx, span := s.tracer.Start(ctx, "Allows")
+	defer span.End()
+
+	latest, err := s.latest(ctx, chargeID)
+	if err != nil || latest == nil {
+		return err
+	}
+
+	for _, state := range to {
+		if CanTransition(latest.To, state) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: charge %s is %s", ErrIllegalTransition, chargeID, latest.To)
+}
+
+// advance records the transitions taking a charge to a state. With fill set,
+// a single missed intermediate state is recorded first when the direct
+// transition isn't allowed.
+func (s *Service) advance(ctx context.Context, chargeID, to, source, eventID string, fill bool) (*Transition, error) {
+	if chargeID == "" {
+		return nil, fmt.Errorf("charge ID cannot be empty")
+	}
+	if !IsState(to) {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownState, to)
+	}
+
+	latest, err := s.latest(ctx, chargeID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every charge's log starts with created
+	from := StateCreated
+	var plan []string
+	if latest == nil {
+		plan = append(plan, StateCreated)
+	} else {
+		from = latest.To
+	}
+
+	if from == to {
+		if latest != nil {
+			return latest, nil
+		}
+	} else {
+		if !CanTransition(from, to) && fill {
+			for _, state := range fillOrder {
+				if CanTransition(from, state) && CanTransition(state, to) {
+					plan = append(plan, state)
+					from = state
+					break
+				}
+			}
+		}
+		if !CanTransition(from, to) {
+			return nil, fmt.Errorf("%w: %s to %s", ErrIllegalTransition, from, to)
+		}
+		plan = append(plan, to)
+	}
+
+	for _, state := range plan {
+		if latest, err = s.append(ctx, chargeID, latest, state, source, eventID); err != nil {
+			return nil, err
+		}
+	}
+
+	return latest, nil
+}
+
+// append records a transition following the latest one
+func (s *Service) append(ctx context.Context, chargeID string, latest *Transition, to, source, eventID string) (*Transition, error) {
+	transition := &Transition{
+		ID:       fmt.Sprintf("ctr_%s", uuid.New().String()),
+		ChargeID: chargeID,
+		Sequence: 1,
+		To:       to,
+		Source:   source,
+		EventID:  eventID,
+	}
+	if latest != nil {
+		transition.Sequence = latest.Sequence + 1
+		transition.From = latest.To
+	}
+
+	recorded, err := s.store.AppendChargeTransition(ctx, transition)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrConcurrentTransition, chargeID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.emitter.Emit(ctx, "charges", EventTransitioned, chargeID, recorded); err != nil {
+		log.Printf("Failed to emit transition for charge %s: %v", chargeID, err)
+	}
+
+	return recorded, nil
+}
+
+// latest returns a charge's latest transition, or nil if it has none
+func (s *Service) latest(ctx context.Context, chargeID string) (*Transition, error) {
+	latest, err := s.store.GetLatestChargeTransition(ctx, chargeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return latest, nil
+}
@@ -0,0 +1,85 @@
+package chargestate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"apis/payments/services/stripe"
+
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// ChargeLookup retrieves charges for dispute events, which carry only the charge ID
+type ChargeLookup interface {
+	GetCharge(ctx context.Context, chargeID string) (*stripe.Charge, error)
+}
+
+// chargeEvents are the provider events that carry a charge's latest state
+var chargeEvents = []stripego.EventType{
+	stripego.EventTypeChargePending,
+	stripego.EventTypeChargeSucceeded,
+	stripego.EventTypeChargeFailed,
+	stripego.EventTypeChargeCaptured,
+	stripego.EventTypeChargeExpired,
+	stripego.EventTypeChargeRefunded,
+}
+
+// RegisterWebhookHandlers records transitions from charge and dispute events.
+// Illegal transitions are logged and dropped rather than retried, since a
+// redelivery would be rejected the same way.
+func (s *Service) RegisterWebhookHandlers(webhooks *stripe.WebhookService, charges ChargeLookup) {
+	for _, eventType := range chargeEvents {
+		webhooks.On(eventType, func(ctx context.Context, event stripego.Event) error {
+			var charge stripego.Charge
+			if err := json.Unmarshal(event.Data.Raw, &charge); err != nil {
+				return fmt.Errorf("failed to parse charge: %w", err)
+			}
+
+			_, err := s.Apply(ctx, stripe.ConvertCharge(&charge), SourceWebhook, event.ID)
+			return rejectIllegal(event, err)
+		})
+	}
+
+	webhooks.On(stripego.EventTypeChargeDisputeCreated, func(ctx context.Context, event stripego.Event) error {
+		var dispute stripego.Dispute
+		if err := json.Unmarshal(event.Data.Raw, &dispute); err != nil {
+			return fmt.Errorf("failed to parse dispute: %w", err)
+		}
+		if dispute.Charge == nil {
+			return nil
+		}
+
+		_, err := s.Dispute(ctx, dispute.Charge.ID, SourceWebhook, event.ID)
+		return rejectIllegal(event, err)
+	})
+
+	webhooks.On(stripego.EventTypeChargeDisputeClosed, func(ctx context.Context, event stripego.Event) error {
+		var dispute stripego.Dispute
+		if err := json.Unmarshal(event.Data.Raw, &dispute); err != nil {
+			return fmt.Errorf("failed to parse dispute: %w", err)
+		}
+		if dispute.Charge == nil {
+			return nil
+		}
+
+		charge, err := charges.GetCharge(ctx, dispute.Charge.ID)
+		if err != nil {
+			return fmt.Errorf("failed to look up charge %s: %w", dispute.Charge.ID, err)
+		}
+
+		_, err = s.CloseDispute(ctx, charge, string(dispute.Status), SourceWebhook, event.ID)
+		return rejectIllegal(event, err)
+	})
+}
+
+// rejectIllegal logs and drops illegal transitions, passing other errors on
+func rejectIllegal(event stripego.Event, err error) error {
+	if errors.Is(err, ErrIllegalTransition) {
+		log.Printf("Rejected charge state change from %s event %s: %v", event.Type, event.ID, err)
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"apis/payments/services/refundguard"
+	"apis/payments/services/stripe"
+)
+
+// Command types other services can send
+const (
+	TypeCreateRefund       = "refund.create"
+	TypeCaptureCharge      = "charge.capture"
+	TypeCancelSubscription = "subscription.cancel"
+)
+
+// Event types emitted with the outcome of a command
+const (
+	EventSucceeded = "payments.command.succeeded"
+	EventFailed    = "payments.command.failed"
+)
+
+// CommandMetadataKey links provider objects to the command that created them
+const CommandMetadataKey = "command_id"
+
+// ErrInvalidCommand is returned for commands that cannot be executed as sent
+var ErrInvalidCommand = errors.New("invalid command")
+
+// Command is an operation requested by another service, e.g.
+//
+//	{"id": "cmd_123", "type": "refund.create", "tenant_id": "acme",
+//	 "requested_by": "orders", "data": {"charge_id": "ch_123", "amount": 500}}
+type Command struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	TenantID    string          `json:"tenant_id,omitempty"`
+	RequestedBy string          `json:"requested_by,omitempty"` // Service or operator sending the command
+	Data        json.RawMessage `json:"data"`
+}
+
+// CaptureCharge is the data of a charge.capture command
+type CaptureCharge struct {
+	ChargeID string `json:"charge_id"`
+	Amount   int64  `json:"amount,omitempty"` // Captures in full when zero
+}
+
+// CancelSubscription is the data of a subscription.cancel command
+type CancelSubscription struct {
+	SubscriptionID string `json:"subscription_id"`
+	AtPeriodEnd    bool   `json:"at_period_end,omitempty"`
+}
+
+// Outcome is the data of the event emitted once a command is executed
+type Outcome struct {
+	CommandID string `json:"command_id"`
+	Type      string `json:"type"`
+	Result    any    `json:"result,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Refunder issues refunds subject to the refund guard's velocity limits
+type Refunder interface {
+	CreateRefund(ctx context.Context, actor refundguard.Actor, request *stripe.RefundRequest) (*stripe.Refund, *refundguard.Approval, error)
+}
+
+// ChargeStates checks that a charge may move to a state
+type ChargeStates interface {
+	Allows(ctx context.Context, chargeID string, to ...string) error
+}
+
+// Capturer captures authorized charges
+type Capturer interface {
+	CaptureCharge(ctx context.Context, chargeID string, amount int64) (*stripe.Charge, error)
+}
+
+// Canceller cancels subscriptions
+type Canceller interface {
+	CancelSubscription(ctx context.Context, subscriptionID string, atPeriodEnd bool) (*stripe.Subscription, error)
+}
@@ -0,0 +1,168 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"apis/payments/services/chargestate"
+	"apis/payments/services/events"
+	"apis/payments/services/kafka"
+	"apis/payments/services/money"
+	"apis/payments/services/refundguard"
+	"apis/payments/services/stripe"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Service executes commands consumed from other services
+type Service struct {
+	refunds       Refunder
+	chargeStates  ChargeStates
+	charges       Capturer
+	subscriptions Canceller
+	emitter       *events.Emitter
+	tracer        trace.Tracer
+}
+
+// NewService creates a new command service
+func NewService(refunds Refunder, chargeStates ChargeStates, charges Capturer, subscriptions Canceller, emitter *events.Emitter) *Service {
+	return &Service{
+		refunds:       refunds,
+		chargeStates:  chargeStates,
+		charges:       charges,
+		subscriptions: subscriptions,
+		emitter:       emitter,
+		tracer:        otel.Tracer("payments.commands"),
+	}
+}
+
+// Handle executes a command read from Kafka. Commands that can never succeed
+// fail permanently so they are dead-lettered without retries; the outcome is
+// emitted once the command succeeds or fails permanently.
+func (s *Service) Handle(ctx context.Context, message *kafka.Message) error {
+	var command Command
+	if err := json.Unmarshal(message.Value, &command); err != nil {
+		return fmt.Errorf("%w: %w: %v", kafka.ErrPermanent, ErrInvalidCommand, err)
+	}
+
+	result, err := s.Execute(ctx, &command)
+	if err != nil {
+		if !permanent(err) {
+			return err
+		}
+		s.emit(ctx, EventFailed, &Outcome{CommandID: command.ID, Type: command.Type, Error: err.Error()})
+		return fmt.Errorf("%w: %w", kafka.ErrPermanent, err)
+	}
+
+	s.emit(ctx, EventSucceeded, &Outcome{CommandID: command.ID, Type: command.Type, Result: result})
+	return nil
+}
+
+// Execute runs a command and returns the resource it created or changed
+func (s *Service) Execute(ctx context.Context, command *Command) (any, error) {
+	ctx, span := s.tracer.Start(ctx, "Execute", trace.WithAttributes(
+		attribute.String("command.id", command.ID),
+		attribute.String("command.type", command.Type),
+	))
+	defer span.End()
+
+	if command.ID == "" {
+		return nil, fmt.Errorf("%w: id is required", ErrInvalidCommand)
+	}
+
+	switch command.Type {
+	case TypeCreateRefund:
+		var request stripe.RefundRequest
+		if err := decode(command, &request); err != nil {
+			return nil, err
+		}
+		return s.createRefund(ctx, command, &request)
+
+	case TypeCaptureCharge:
+		var request CaptureCharge
+		if err := decode(command, &request); err != nil {
+			return nil, err
+		}
+		if request.ChargeID == "" {
+			return nil, fmt.Errorf("%w: charge_id is required", ErrInvalidCommand)
+		}
+		return s.charges.CaptureCharge(ctx, request.ChargeID, request.Amount)
+
+	case TypeCancelSubscription:
+		var request CancelSubscription
+		if err := decode(command, &request); err != nil {
+			return nil, err
+		}
+		if request.SubscriptionID == "" {
+			return nil, fmt.Errorf("%w: subscription_id is required", ErrInvalidCommand)
+		}
+		return s.subscriptions.CancelSubscription(ctx, request.SubscriptionID, request.AtPeriodEnd)
+
+	default:
+		return nil, fmt.Errorf("%w: unknown type %q", ErrInvalidCommand, command.Type)
+	}
+}
+
+// createRefund issues a refund the way the API does, held for approval when
+// it breaches the refund guard's limits. The command ID is the idempotency
+// key, so a redelivered command returns the refund it already created.
+func (s *Service) createRefund(ctx context.Context, command *Command, request *stripe.RefundRequest) (any, error) {
+	if request.ChargeID == "" {
+		return nil, fmt.Errorf("%w: charge_id is required", ErrInvalidCommand)
+	}
+
+	// Only captured charges can be refunded
+	err := s.chargeStates.Allows(ctx, request.ChargeID, chargestate.StatePartiallyRefunded, chargestate.StateRefunded)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Metadata == nil {
+		request.Metadata = make(map[string]string)
+	}
+	request.Metadata[CommandMetadataKey] = command.ID
+	request.IdempotencyKey = "command-" + command.ID
+
+	actor := refundguard.Actor{TenantID: command.TenantID, OperatorID: command.RequestedBy}
+	refund, approval, err := s.refunds.CreateRefund(ctx, actor, request)
+	if err != nil {
+		return nil, err
+	}
+	if approval != nil {
+		return approval, nil
+	}
+
+	return refund, nil
+}
+
+// emit publishes a command's outcome, logging failures since the command
+// itself has already been executed
+func (s *Service) emit(ctx context.Context, eventType string, outcome *Outcome) {
+	if err := s.emitter.Emit(ctx, "commands", eventType, outcome.CommandID, outcome); err != nil {
+		log.Printf("Failed to emit outcome of command %s: %v", outcome.CommandID, err)
+	}
+}
+
+// decode reads a command's data into its request type
+func decode(command *Command, request any) error {
+	if len(command.Data) == 0 {
+		return fmt.Errorf("%w: data is required", ErrInvalidCommand)
+	}
+	if err := json.Unmarshal(command.Data, request); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCommand, err)
+	}
+	return nil
+}
+
+// permanent reports whether a command error would recur on every retry
+func permanent(err error) bool {
+	return errors.Is(err, ErrInvalidCommand) ||
+		errors.Is(err, chargestate.ErrIllegalTransition) ||
+		errors.Is(err, money.ErrInvalidDecimal) ||
+		errors.Is(err, money.ErrAmountMismatch)
+}
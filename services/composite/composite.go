@@ -0,0 +1,200 @@
+package composite
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Refund strategies deciding how a refund is split across legs
+const (
+	// StrategyProportional splits refunds in proportion to what each leg can
+	// still refund
+	StrategyProportional = "proportional"
+	// StrategyPriority refunds legs in priority order, lowest first, moving to
+	// the next leg once one is fully refunded
+	StrategyPriority = "priority"
+)
+
+// Composite refund statuses
+const (
+	StatusPending         = "pending"
+	StatusSucceeded       = "succeeded"
+	StatusPartiallyFailed = "partially_failed"
+	StatusFailed          = "failed"
+)
+
+// Leg refund statuses. Legs held by the refund guard wait for approval.
+const (
+	LegStatusPending         = "pending"
+	LegStatusPendingApproval = "pending_approval"
+	LegStatusSucceeded       = "succeeded"
+	LegStatusFailed          = "failed"
+	LegStatusCanceled        = "canceled"
+)
+
+// RefundMetadataKey links provider refunds to the composite refund they belong to
+const RefundMetadataKey = "composite_refund_id"
+
+// ErrInvalidCompositeCharge is returned when legs cannot form a composite charge
+var ErrInvalidCompositeCharge = errors.New("invalid composite charge")
+
+// ErrInvalidRefund is returned when a refund request cannot be split
+var ErrInvalidRefund = errors.New("invalid composite refund")
+
+// ErrExceedsRefundable is returned when a refund is larger than the legs can refund
+var ErrExceedsRefundable = errors.New("refund exceeds the refundable amount")
+
+// Charge is an order paid across several payment methods, one charge per leg
+type Charge struct {
+	ID             string    `json:"id"`
+	TenantID       string    `json:"tenant_id"`
+	Amount         int64     `json:"amount"`
+	Currency       string    `json:"currency"`
+	RefundStrategy string    `json:"refund_strategy"`
+	Legs           []*Leg    `json:"legs"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Leg is one charge of a composite charge
+type Leg struct {
+	ChargeID string `json:"charge_id"`
+	Amount   int64  `json:"amount"`
+	Priority int    `json:"priority"` // Lower priorities are refunded first by StrategyPriority
+}
+
+// Refund is a refund of a composite charge, issued as one refund per leg
+type Refund struct {
+	ID                string       `json:"id"`
+	CompositeChargeID string       `json:"composite_charge_id"`
+	TenantID          string       `json:"tenant_id"`
+	Amount            int64        `json:"amount"`
+	Currency          string       `json:"currency"`
+	Reason            string       `json:"reason,omitempty"`
+	Status            string       `json:"status"`
+	Legs              []*LegRefund `json:"legs"`
+	CreatedAt         time.Time    `json:"created_at"`
+	UpdatedAt         time.Time    `json:"updated_at"`
+}
+
+// LegRefund is the part of a composite refund issued against one leg
+type LegRefund struct {
+	ChargeID      string    `json:"charge_id"`
+	Amount        int64     `json:"amount"`
+	RefundID      string    `json:"refund_id,omitempty"`   // Provider refund ID
+	ApprovalID    string    `json:"approval_id,omitempty"` // Set while held for approval
+	Status        string    `json:"status"`
+	FailureReason string    `json:"failure_reason,omitempty"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// CreateChargeRequest groups existing charges into a composite charge
+type CreateChargeRequest struct {
+	Legs           []LegRequest `json:"legs"`
+	RefundStrategy string       `json:"refund_strategy,omitempty"` // Defaults to proportional
+}
+
+// LegRequest names a charge to add as a leg of a composite charge
+type LegRequest struct {
+	ChargeID string `json:"charge_id"`
+	Priority int    `json:"priority,omitempty"`
+}
+
+// RefundRequest refunds a composite charge, in full when no amount is given
+type RefundRequest struct {
+	Amount        int64  `json:"amount,omitempty"`
+	AmountDecimal string `json:"amount_decimal,omitempty"` // Alternative to amount in the charge's currency
+	Reason        string `json:"reason,omitempty"`
+	Strategy      string `json:"strategy,omitempty"` // Overrides the charge's refund strategy
+}
+
+// IsStrategy reports whether a refund strategy is supported
+func IsStrategy(strategy string) bool {
+	return strategy == StrategyProportional || strategy == StrategyPriority
+}
+
+// Split divides an amount across legs, given how much each leg can still
+// refund. Proportional splits round down and hand the leftover minor units to
+// the legs with the largest remainders, earliest leg first on ties, so the
+// parts always add up to the amount.
+func Split(strategy string, legs []*Leg, refundable []int64, amount int64) ([]int64, error) {
+	var total int64
+	for _, available := range refundable {
+		total += available
+	}
+	if amount > total {
+		return nil, fmt.Errorf("%w: %d requested, %d refundable", ErrExceedsRefundable, amount, total)
+	}
+
+	parts := make([]int64, len(legs))
+	switch strategy {
+	case StrategyPriority:
+		order := make([]int, len(legs))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(a, b int) bool {
+			return legs[order[a]].Priority < legs[order[b]].Priority
+		})
+
+		remaining := amount
+		for _, i := range order {
+			parts[i] = min(remaining, refundable[i])
+			remaining -= parts[i]
+		}
+
+	case StrategyProportional:
+		if total == 0 {
+			return parts, nil
+		}
+
+		remainders := make([]int64, len(legs))
+		var allocated int64
+		for i, available := range refundable {
+			parts[i] = amount * available / total
+			remainders[i] = amount * available % total
+			allocated += parts[i]
+		}
+
+		order := make([]int, len(legs))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(a, b int) bool {
+			return remainders[order[a]] > remainders[order[b]]
+		})
+		for _, i := range order[:amount-allocated] {
+			parts[i]++
+		}
+
+	default:
+		return nil, fmt.Errorf("%w: unknown refund strategy %q", ErrInvalidRefund, strategy)
+	}
+
+	return parts, nil
+}
+
+// CombinedStatus derives a composite refund's status from its legs' statuses
+func CombinedStatus(legs []*LegRefund) string {
+	var succeeded, failed int
+	for _, leg := range legs {
+		switch leg.Status {
+		case LegStatusSucceeded:
+			succeeded++
+		case LegStatusFailed, LegStatusCanceled:
+			failed++
+		default:
+			return StatusPending
+		}
+	}
+
+	switch {
+	case failed == 0:
+		return StatusSucceeded
+	case succeeded == 0:
+		return StatusFailed
+	default:
+		return StatusPartiallyFailed
+	}
+}
@@ -0,0 +1,361 @@
+package composite
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"apis/payments/services/money"
+	"apis/payments/services/refundguard"
+	"apis/payments/services/stripe"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Store persists composite charges and the refunds split across their legs
+type Store interface {
+	CreateCompositeCharge(ctx context.Context, charge *Charge) (*Charge, error)
+	GetCompositeCharge(ctx context.Context, id string) (*Charge, error)
+	CreateCompositeRefund(ctx context.Context, refund *Refund) (*Refund, error)
+	GetCompositeRefund(ctx context.Context, id string) (*Refund, error)
+	ListCompositeRefunds(ctx context.Context, compositeChargeID string) ([]*Refund, error)
+	UpdateCompositeRefundLeg(ctx context.Context, refundID string, leg *LegRefund) (*LegRefund, error)
+	UpdateCompositeRefundStatus(ctx context.Context, id, status string) error
+}
+
+// ChargeLookup retrieves leg charges to check what they can still refund
+type ChargeLookup interface {
+	GetCharge(ctx context.Context, chargeID string) (*stripe.Charge, error)
+}
+
+// Refunder issues leg refunds. Refunds go through the refund guard, so a
+// leg may be held for approval instead of refunded.
+type Refunder interface {
+	CreateRefund(ctx context.Context, actor refundguard.Actor, request *stripe.RefundRequest) (*stripe.Refund, *refundguard.Approval, error)
+}
+
+// Service groups charges into composite charges and splits their refunds across legs
+type Service struct {
+	store    Store
+	charges  ChargeLookup
+	refunder Refunder
+	tracer   trace.Tracer
+}
+
+// NewService creates a new composite charge service
+func NewService(store Store, charges ChargeLookup, refunder Refunder) *Service {
+	return &Service{
+		store:    store,
+		charges:  charges,
+		refunder: refunder,
+		tracer:   otel.Tracer("payments.composite"),
+	}
+}
+
+// CreateCharge groups succeeded charges in one currency into a composite charge
+func (s *Service) CreateCharge(ctx context.Context, tenantID string, request *CreateChargeRequest) (*Charge, error) {
+	ctx, span := s.tracer.Start(ctx, "CreateCharge")
+	defer span.End()
+
+	if len(request.Legs) < 2 {
+		return nil, fmt.Errorf("%w: at least two legs are required", ErrInvalidCompositeCharge)
+	}
+
+	strategy := request.RefundStrategy
+	if strategy == "" {
+		strategy = StrategyProportional
+	}
+	if !IsStrategy(strategy) {
+		return nil, fmt.Errorf("%w: unknown refund strategy %q", ErrInvalidCompositeCharge, strategy)
+	}
+
+	composite := &Charge{
+		ID:             "cmp_" + uuid.New().String(),
+		TenantID:       tenantID,
+		RefundStrategy: strategy,
+	}
+
+	seen := make(map[string]bool, len(request.Legs))
+	for _, requested := range request.Legs {
+		if requested.ChargeID == "" || seen[requested.ChargeID] {
+			return nil, fmt.Errorf("%w: every leg needs a distinct charge_id", ErrInvalidCompositeCharge)
+		}
+		seen[requested.ChargeID] = true
+
+		charge, err := s.charges.GetCharge(ctx, requested.ChargeID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get charge %s: %w", requested.ChargeID, err)
+		}
+		if charge.Status != "succeeded" {
+			return nil, fmt.Errorf("%w: charge %s has not succeeded", ErrInvalidCompositeCharge, charge.ID)
+		}
+		if composite.Currency == "" {
+			composite.Currency = charge.Currency
+		}
+		if charge.Currency != composite.Currency {
+			return nil, fmt.Errorf("%w: every leg must be charged in %s", ErrInvalidCompositeCharge, composite.Currency)
+		}
+
+		composite.Amount += charge.Amount
+		composite.Legs = append(composite.Legs, &Leg{
+			ChargeID: charge.ID,
+			Amount:   charge.Amount,
+			Priority: requested.Priority,
+		})
+	}
+
+	return s.store.CreateCompositeCharge(ctx, composite)
+}
+
+// GetCharge retrieves a composite charge with its legs
+func (s *Service) GetCharge(ctx context.Context, id string) (*Charge, error) {
+	ctx, span := s.tracer.Start(ctx, "GetCharge")
+	defer span.End()
+
+	return s.store.GetCompositeCharge(ctx, id)
+}
+
+// Refund splits a refund across a composite charge's legs and issues one
+// refund per leg. Legs are refunded independently, so the composite refund
+// reports which legs succeeded, failed or are held for approval.
+func (s *Service) Refund(ctx context.Context, actor refundguard.Actor, compositeID string, request *RefundRequest) (*Refund, error) {
+	ctx, span := s.tracer.Start(ctx, "Refund")
+	defer span.End()
+
+	composite, err := s.store.GetCompositeCharge(ctx, compositeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get composite charge: %w", err)
+	}
+
+	strategy := request.Strategy
+	if strategy == "" {
+		strategy = composite.RefundStrategy
+	}
+	if !IsStrategy(strategy) {
+		return nil, fmt.Errorf("%w: unknown refund strategy %q", ErrInvalidRefund, strategy)
+	}
+
+	amount, err := money.ResolveAmount(request.Amount, request.AmountDecimal, composite.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if amount < 0 {
+		return nil, fmt.Errorf("%w: amount cannot be negative", ErrInvalidRefund)
+	}
+
+	refundable, err := s.refundable(ctx, composite)
+	if err != nil {
+		return nil, err
+	}
+
+	// Refund whatever is left when no amount is given
+	if amount == 0 {
+		for _, available := range refundable {
+			amount += available
+		}
+		if amount == 0 {
+			return nil, fmt.Errorf("%w: the charge is fully refunded", ErrExceedsRefundable)
+		}
+	}
+
+	parts, err := Split(strategy, composite.Legs, refundable, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	refund := &Refund{
+		ID:                "crf_" + uuid.New().String(),
+		CompositeChargeID: composite.ID,
+		TenantID:          composite.TenantID,
+		Amount:            amount,
+		Currency:          composite.Currency,
+		Reason:            request.Reason,
+		Status:            StatusPending,
+	}
+	for i, leg := range composite.Legs {
+		if parts[i] > 0 {
+			refund.Legs = append(refund.Legs, &LegRefund{ChargeID: leg.ChargeID, Amount: parts[i], Status: LegStatusPending})
+		}
+	}
+
+	// The split is stored before any leg is refunded so webhooks for the
+	// leg refunds always find it
+	refund, err = s.store.CreateCompositeRefund(ctx, refund)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create composite refund: %w", err)
+	}
+
+	for i, leg := range refund.Legs {
+		s.refundLeg(ctx, actor, refund, leg)
+
+		updated, err := s.store.UpdateCompositeRefundLeg(ctx, refund.ID, leg)
+		if err != nil {
+			log.Printf("Failed to record refund of leg %s of composite refund %s: %v", leg.ChargeID, refund.ID, err)
+			continue
+		}
+		refund.Legs[i] = updated
+	}
+
+	refund.Status = CombinedStatus(refund.Legs)
+	if err := s.store.UpdateCompositeRefundStatus(ctx, refund.ID, refund.Status); err != nil {
+		return nil, fmt.Errorf("failed to update composite refund status: %w", err)
+	}
+
+	return refund, nil
+}
+
+// GetRefund retrieves a composite refund with its legs
+func (s *Service) GetRefund(ctx context.Context, id string) (*Refund, error) {
+	ctx, span := s.tracer.Start(ctx, "GetRefund")
+	defer span.End()
+
+	return s.store.GetCompositeRefund(ctx, id)
+}
+
+// ListRefunds lists the refunds of a composite charge, oldest first
+func (s *Service) ListRefunds(ctx context.Context, compositeChargeID string) ([]*Refund, error) {
+	ctx, span := s.tracer.Start(ctx, "ListRefunds")
+	defer span.End()
+
+	return s.store.ListCompositeRefunds(ctx, compositeChargeID)
+}
+
+// HandleRefundUpdate records the latest status of a leg refund reported by
+// the provider and updates the composite refund's combined status
+func (s *Service) HandleRefundUpdate(ctx context.Context, compositeRefundID string, providerRefund *stripe.Refund, failureReason string) error {
+	ctx, span := s.tracer.Start(ctx, "HandleRefundUpdate")
+	defer span.End()
+
+	refund, err := s.store.GetCompositeRefund(ctx, compositeRefundID)
+	if err != nil {
+		return fmt.Errorf("failed to get composite refund: %w", err)
+	}
+
+	for i, leg := range refund.Legs {
+		if leg.ChargeID != providerRefund.ChargeID {
+			continue
+		}
+
+		// Held legs get their refund ID once an approver issues them
+		leg.RefundID = providerRefund.ID
+		leg.ApprovalID = ""
+		leg.Status = legStatus(providerRefund.Status)
+		leg.FailureReason = failureReason
+
+		updated, err := s.store.UpdateCompositeRefundLeg(ctx, refund.ID, leg)
+		if err != nil {
+			return fmt.Errorf("failed to update leg refund: %w", err)
+		}
+		refund.Legs[i] = updated
+
+		status := CombinedStatus(refund.Legs)
+		if status == refund.Status {
+			return nil
+		}
+		return s.store.UpdateCompositeRefundStatus(ctx, refund.ID, status)
+	}
+
+	return nil
+}
+
+// HandleApprovalRejected cancels a leg refund whose approval was rejected
+func (s *Service) HandleApprovalRejected(ctx context.Context, approval *refundguard.Approval) error {
+	ctx, span := s.tracer.Start(ctx, "HandleApprovalRejected")
+	defer span.End()
+
+	compositeRefundID := approval.Request.Metadata[RefundMetadataKey]
+	if compositeRefundID == "" {
+		return nil
+	}
+
+	refund, err := s.store.GetCompositeRefund(ctx, compositeRefundID)
+	if err != nil {
+		return fmt.Errorf("failed to get composite refund: %w", err)
+	}
+
+	for i, leg := range refund.Legs {
+		if leg.ApprovalID != approval.ID {
+			continue
+		}
+
+		leg.Status = LegStatusCanceled
+		leg.FailureReason = "refund approval was rejected"
+
+		updated, err := s.store.UpdateCompositeRefundLeg(ctx, refund.ID, leg)
+		if err != nil {
+			return fmt.Errorf("failed to update leg refund: %w", err)
+		}
+		refund.Legs[i] = updated
+
+		return s.store.UpdateCompositeRefundStatus(ctx, refund.ID, CombinedStatus(refund.Legs))
+	}
+
+	return nil
+}
+
+// refundable returns how much each leg can still refund. Leg refunds held for
+// approval are not yet known to the provider, so they are set aside here.
+func (s *Service) refundable(ctx context.Context, composite *Charge) ([]int64, error) {
+	held := make(map[string]int64)
+	refunds, err := s.store.ListCompositeRefunds(ctx, composite.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list composite refunds: %w", err)
+	}
+	for _, refund := range refunds {
+		for _, leg := range refund.Legs {
+			if leg.Status == LegStatusPendingApproval {
+				held[leg.ChargeID] += leg.Amount
+			}
+		}
+	}
+
+	refundable := make([]int64, len(composite.Legs))
+	for i, leg := range composite.Legs {
+		charge, err := s.charges.GetCharge(ctx, leg.ChargeID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get charge %s: %w", leg.ChargeID, err)
+		}
+		refundable[i] = max(charge.Amount-charge.AmountRefunded-held[leg.ChargeID], 0)
+	}
+
+	return refundable, nil
+}
+
+// refundLeg issues the refund of one leg and records the outcome on it
+func (s *Service) refundLeg(ctx context.Context, actor refundguard.Actor, refund *Refund, leg *LegRefund) {
+	request := &stripe.RefundRequest{
+		ChargeID: leg.ChargeID,
+		Amount:   leg.Amount,
+		Reason:   refund.Reason,
+		Metadata: map[string]string{RefundMetadataKey: refund.ID},
+	}
+
+	providerRefund, approval, err := s.refunder.CreateRefund(ctx, actor, request)
+	switch {
+	case err != nil:
+		leg.Status = LegStatusFailed
+		leg.FailureReason = err.Error()
+	case approval != nil:
+		leg.Status = LegStatusPendingApproval
+		leg.ApprovalID = approval.ID
+	default:
+		leg.Status = legStatus(providerRefund.Status)
+		leg.RefundID = providerRefund.ID
+	}
+}
+
+// legStatus maps a provider refund status to a leg refund status
+func legStatus(status string) string {
+	switch status {
+	case "succeeded":
+		return LegStatusSucceeded
+	case "failed":
+		return LegStatusFailed
+	case "canceled":
+		return LegStatusCanceled
+	default:
+		return LegStatusPending
+	}
+}
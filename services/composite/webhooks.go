@@ -0,0 +1,38 @@
+package composite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"apis/payments/services/stripe"
+
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// refundEvents report leg refunds being issued and settling
+var refundEvents = []stripego.EventType{
+	stripego.EventTypeRefundCreated,
+	stripego.EventTypeRefundUpdated,
+	stripego.EventTypeChargeRefundUpdated,
+}
+
+// RegisterWebhookHandlers keeps leg refund statuses in step with Stripe
+func (s *Service) RegisterWebhookHandlers(webhooks *stripe.WebhookService) {
+	for _, eventType := range refundEvents {
+		webhooks.On(eventType, func(ctx context.Context, event stripego.Event) error {
+			var stripeRefund stripego.Refund
+			if err := json.Unmarshal(event.Data.Raw, &stripeRefund); err != nil {
+				return fmt.Errorf("failed to parse refund: %w", err)
+			}
+
+			compositeRefundID := stripeRefund.Metadata[RefundMetadataKey]
+			if compositeRefundID == "" || stripeRefund.Charge == nil {
+				return nil
+			}
+
+			refund := stripe.ConvertRefund(&stripeRefund)
+			return s.HandleRefundUpdate(ctx, compositeRefundID, refund, string(stripeRefund.FailureReason))
+		})
+	}
+}
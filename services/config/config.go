@@ -0,0 +1,132 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Kinds of setting values
+const (
+	KindString   = "string"
+	KindInt      = "int"
+	KindFloat    = "float"
+	KindBool     = "bool"
+	KindDuration = "duration" // e.g. 30s or 5m
+	KindURL      = "url"
+)
+
+// Sources a setting's value came from
+const (
+	SourceDefault    = "default" // Unset; the service's default applies
+	SourceEnv        = "env"
+	SourceFile       = "file"
+	SourceSecretFile = "secret_file" // Read from the file named by <NAME>_FILE
+	SourceVault      = "vault"
+)
+
+// ErrInvalid is returned for settings that fail validation
+var ErrInvalid = errors.New("invalid configuration")
+
+// Setting describes one configuration variable. Services read settings from
+// the environment; the loader fills it in from the config file and secret
+// stores first.
+type Setting struct {
+	Name     string
+	Kind     string
+	Required bool
+	// Secret settings are never reported with their value
+	Secret bool
+	// Reloadable settings are re-read from the config file on reload. Others,
+	// including every credential, take effect on restart.
+	Reloadable bool
+	// Check validates the value beyond its kind
+	Check func(value string) error
+}
+
+// Validate checks the value of a setting, which may be empty
+func (s Setting) Validate(value string) error {
+	if value == "" {
+		if s.Required {
+			return fmt.Errorf("%s is required", s.Name)
+		}
+		return nil
+	}
+
+	var err error
+	switch s.Kind {
+	case KindInt:
+		_, err = strconv.Atoi(value)
+	case KindFloat:
+		_, err = strconv.ParseFloat(value, 64)
+	case KindBool:
+		_, err = strconv.ParseBool(value)
+	case KindDuration:
+		_, err = time.ParseDuration(value)
+	case KindURL:
+		var parsed *url.URL
+		if parsed, err = url.Parse(value); err == nil && (parsed.Scheme == "" || parsed.Host == "") {
+			err = errors.New("missing scheme or host")
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%s must be a %s", s.Name, s.Kind)
+	}
+
+	if s.Check != nil {
+		if err := s.Check(value); err != nil {
+			return fmt.Errorf("%s: %w", s.Name, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks every setting against the environment, returning all
+// failures at once
+func Validate(settings []Setting) error {
+	var errs []error
+	for _, setting := range settings {
+		if err := setting.Validate(os.Getenv(setting.Name)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", ErrInvalid, errors.Join(errs...))
+	}
+	return nil
+}
+
+// Status describes where a setting's value came from
+type Status struct {
+	Name       string `json:"name"`
+	Source     string `json:"source"`
+	Value      string `json:"value,omitempty"` // Omitted for secrets
+	Reloadable bool   `json:"reloadable"`
+}
+
+// Int reads an integer setting from the environment, falling back to the
+// default when it is unset or not an integer. Settings registered with the
+// loader are checked at startup, so only unregistered ones fall back
+// silently.
+func Int(name string, defaultValue int) int {
+	if value := os.Getenv(name); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
+
+// Float reads a float setting from the environment, falling back to the
+// default when it is unset or not a number
+func Float(name string, defaultValue float64) float64 {
+	if value := os.Getenv(name); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
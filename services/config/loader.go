@@ -0,0 +1,346 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fileSuffix names the variable holding the path of a setting's secret file
+const fileSuffix = "_FILE"
+
+// Loader layers settings into the environment: the environment wins over
+// the config file, and services keep reading their settings with
+// os.Getenv. Known settings left unset are read from the file <NAME>_FILE
+// names, as Docker and Kubernetes mount secrets, and values of the form
+// vault:<path>#<field> are read from Vault.
+type Loader struct {
+	path     string
+	settings map[string]Setting
+
+	mu          sync.Mutex
+	environment map[string]bool   // Non-empty variables set before loading
+	file        map[string]string // Values applied from the config file
+	sources     map[string]string
+	hooks       []func(changed []string)
+}
+
+// NewLoader creates a loader for a YAML config file, which may be empty to
+// use only the environment
+func NewLoader(path string, settings []Setting) *Loader {
+	l := &Loader{
+		path:        path,
+		settings:    make(map[string]Setting, len(settings)),
+		environment: make(map[string]bool),
+		file:        make(map[string]string),
+		sources:     make(map[string]string),
+	}
+	for _, setting := range settings {
+		l.settings[setting.Name] = setting
+	}
+	return l
+}
+
+// Load applies the config file and secrets to the environment and validates
+// the settings
+func (l *Loader) Load(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, entry := range os.Environ() {
+		// Empty variables, as left by copies of env.example, count as unset
+		name, value, _ := strings.Cut(entry, "=")
+		if value == "" {
+			continue
+		}
+		l.environment[name] = true
+		l.sources[name] = SourceEnv
+	}
+
+	values, err := l.readFile()
+	if err != nil {
+		return err
+	}
+	for name, value := range values {
+		if l.environment[name] {
+			continue
+		}
+		os.Setenv(name, value)
+		l.file[name] = value
+		l.sources[name] = SourceFile
+	}
+
+	if err := l.readSecretFiles(); err != nil {
+		return err
+	}
+	if err := l.readVault(ctx); err != nil {
+		return err
+	}
+
+	return Validate(l.list())
+}
+
+// readFile reads the config file's settings. Nested keys are joined with
+// underscores and upper-cased, so rate_limit: {rate: 5} sets RATE_LIMIT_RATE;
+// lists are joined with commas.
+func (l *Loader) readFile() (map[string]string, error) {
+	values := make(map[string]string)
+	if l.path == "" {
+		return values, nil
+	}
+
+	data, err := os.ReadFile(l.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var document map[string]any
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if err := flatten("", document, values); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	return values, nil
+}
+
+// flatten adds the scalar values of a YAML mapping to values by variable name
+func flatten(prefix string, document map[string]any, values map[string]string) error {
+	for key, value := range document {
+		name := strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+		if prefix != "" {
+			name = prefix + "_" + name
+		}
+
+		switch v := value.(type) {
+		case map[string]any:
+			if err := flatten(name, v, values); err != nil {
+				return err
+			}
+		case []any:
+			items := make([]string, len(v))
+			for i, item := range v {
+				scalar, ok := scalarString(item)
+				if !ok {
+					return fmt.Errorf("%s: lists may only hold values", key)
+				}
+				items[i] = scalar
+			}
+			values[name] = strings.Join(items, ",")
+		default:
+			scalar, ok := scalarString(v)
+			if !ok {
+				return fmt.Errorf("%s: unsupported value", key)
+			}
+			values[name] = scalar
+		}
+	}
+	return nil
+}
+
+// scalarString formats a YAML scalar as an environment variable value
+func scalarString(value any) (string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return "", true
+	case string:
+		return v, true
+	case bool:
+		return strconv.FormatBool(v), true
+	case int:
+		return strconv.Itoa(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	default:
+		return "", false
+	}
+}
+
+// readSecretFiles sets each unset setting from the file <NAME>_FILE names.
+// Only known settings are read, since variables such as CLIENT_CERT_FILE
+// already name files.
+func (l *Loader) readSecretFiles() error {
+	for name := range l.settings {
+		path := os.Getenv(name + fileSuffix)
+		if path == "" || os.Getenv(name) != "" {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s%s: %w", name, fileSuffix, err)
+		}
+		os.Setenv(name, strings.TrimRight(string(data), "\r\n"))
+		l.sources[name] = SourceSecretFile
+	}
+	return nil
+}
+
+// readVault replaces vault:<path>#<field> values with the secrets they name
+func (l *Loader) readVault(ctx context.Context) error {
+	var vault *vaultClient
+	for _, entry := range os.Environ() {
+		name, value, _ := strings.Cut(entry, "=")
+		reference, ok := strings.CutPrefix(value, vaultPrefix)
+		if !ok {
+			continue
+		}
+
+		if vault == nil {
+			var err error
+			if vault, err = newVaultClient(); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		secret, err := vault.read(ctx, reference)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		os.Setenv(name, secret)
+		l.sources[name] = SourceVault
+	}
+	return nil
+}
+
+// Reload re-reads the reloadable settings from the config file and returns
+// the names of those that changed. Settings from the environment keep their
+// values, and nothing is applied unless every changed setting is valid.
+func (l *Loader) Reload() ([]string, error) {
+	l.mu.Lock()
+
+	values, err := l.readFile()
+	if err != nil {
+		l.mu.Unlock()
+		return nil, err
+	}
+
+	var changed []string
+	var errs []error
+	for name, setting := range l.settings {
+		if !setting.Reloadable || l.environment[name] {
+			continue
+		}
+		value, ok := values[name]
+		if previous, applied := l.file[name]; value == previous && ok == applied {
+			continue
+		}
+		if err := setting.Validate(value); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		changed = append(changed, name)
+	}
+	if len(errs) > 0 {
+		l.mu.Unlock()
+		return nil, fmt.Errorf("%w: %w", ErrInvalid, errors.Join(errs...))
+	}
+
+	sort.Strings(changed)
+	for _, name := range changed {
+		if value, ok := values[name]; ok {
+			os.Setenv(name, value)
+			l.file[name] = value
+			l.sources[name] = SourceFile
+		} else {
+			os.Unsetenv(name)
+			delete(l.file, name)
+			delete(l.sources, name)
+		}
+	}
+	hooks := l.hooks
+	l.mu.Unlock()
+
+	if len(changed) > 0 {
+		for _, hook := range hooks {
+			hook(changed)
+		}
+	}
+	return changed, nil
+}
+
+// OnReload registers a hook called with the settings that changed on reload
+func (l *Loader) OnReload(hook func(changed []string)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.hooks = append(l.hooks, hook)
+}
+
+// Watch reloads the config file on SIGHUP until the returned stop function
+// is called
+func (l *Loader) Watch() (stop func()) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-signals:
+				changed, err := l.Reload()
+				if err != nil {
+					log.Printf("Config reload failed, keeping the current settings: %v", err)
+					continue
+				}
+				log.Printf("Config reloaded, %d settings changed: %s", len(changed), strings.Join(changed, ", "))
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+		<-stopped
+	}
+}
+
+// Statuses describes the known settings by name: where each value came
+// from, and the value unless it is secret
+func (l *Loader) Statuses() []Status {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	statuses := make([]Status, 0, len(l.settings))
+	for _, setting := range l.list() {
+		status := Status{
+			Name:       setting.Name,
+			Source:     SourceDefault,
+			Reloadable: setting.Reloadable,
+		}
+		if value := os.Getenv(setting.Name); value != "" {
+			status.Source = l.sources[setting.Name]
+			if !setting.Secret {
+				status.Value = value
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// list returns the settings by name
+func (l *Loader) list() []Setting {
+	settings := make([]Setting, 0, len(l.settings))
+	for _, setting := range l.settings {
+		settings = append(settings, setting)
+	}
+	sort.Slice(settings, func(i, j int) bool {
+		return settings[i].Name < settings[j].Name
+	})
+	return settings
+}
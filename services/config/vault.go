@@ -0,0 +1,99 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// vaultPrefix marks values read from Vault, e.g.
+// vault:secret/data/payments#stripe_secret_key
+const vaultPrefix = "vault:"
+
+// vaultClient reads secrets from Vault's HTTP API
+type vaultClient struct {
+	addr   string
+	token  string
+	client *http.Client
+	cache  map[string]map[string]any // Secrets by path, read once per load
+}
+
+// newVaultClient creates a client for the Vault at VAULT_ADDR, which may be
+// given a token with VAULT_TOKEN or VAULT_TOKEN_FILE
+func newVaultClient() (*vaultClient, error) {
+	addr := strings.TrimRight(os.Getenv("VAULT_ADDR"), "/")
+	token := os.Getenv("VAULT_TOKEN")
+	if addr == "" || token == "" {
+		return nil, errors.New("vault references require VAULT_ADDR and VAULT_TOKEN")
+	}
+
+	return &vaultClient{
+		addr:   addr,
+		token:  token,
+		client: &http.Client{Timeout: 10 * time.Second},
+		cache:  make(map[string]map[string]any),
+	}, nil
+}
+
+// read returns a field of a secret, referenced as <path>#<field>. Both KV
+// version 1 and 2 secrets are read; version 2 paths include data/.
+func (c *vaultClient) read(ctx context.Context, reference string) (string, error) {
+	path, field, ok := strings.Cut(reference, "#")
+	if !ok || path == "" || field == "" {
+		return "", fmt.Errorf("vault reference %q must be <path>#<field>", reference)
+	}
+
+	secret, ok := c.cache[path]
+	if !ok {
+		var err error
+		if secret, err = c.fetch(ctx, path); err != nil {
+			return "", err
+		}
+		c.cache[path] = secret
+	}
+
+	value, ok := secret[field].(string)
+	if !ok {
+		return "", fmt.Errorf("vault secret %s has no field %s", path, field)
+	}
+	return value, nil
+}
+
+// fetch reads a secret's fields
+func (c *vaultClient) fetch(ctx context.Context, path string) (map[string]any, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.addr+"/v1/"+strings.TrimLeft(path, "/"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create vault request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", c.token)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault secret %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to read vault secret %s: status %d", path, resp.StatusCode)
+	}
+
+	var body struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to parse vault secret %s: %w", path, err)
+	}
+
+	// KV version 2 nests the fields under data with the secret's metadata
+	if nested, ok := body.Data["data"].(map[string]any); ok {
+		if _, versioned := body.Data["metadata"]; versioned {
+			return nested, nil
+		}
+	}
+	return body.Data, nil
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"context"
+
+	"apis/payments/services/stripe"
+)
+
+// CredentialVerifier checks tenant-supplied provider credentials by making
+// the cheapest authenticated call each provider offers
+type CredentialVerifier struct{}
+
+// NewCredentialVerifier creates a new credential verifier
+func NewCredentialVerifier() *CredentialVerifier {
+	return &CredentialVerifier{}
+}
+
+// Verify validates credentials against the provider's configuration rules,
+// then makes a test call with them
+func (v *CredentialVerifier) Verify(ctx context.Context, provider string, fields map[string]string) error {
+	config := make(map[string]interface{}, len(fields))
+	for key, value := range fields {
+		config[key] = value
+	}
+
+	if err := ValidateProviderConfig(provider, config); err != nil {
+		return err
+	}
+
+	switch provider {
+	case "stripe":
+		// NewStripeGateway would replace the process-wide key
+		return stripe.VerifySecretKey(ctx, fields["api_key"])
+	case "paddle":
+		gateway, err := NewPaddleGateway(config)
+		if err != nil {
+			return err
+		}
+		_, err = gateway.ListCustomers(ctx, ListCustomersRequest{Limit: 1})
+		return err
+	case "square":
+		gateway, err := NewSquareGateway(config)
+		if err != nil {
+			return err
+		}
+		_, err = gateway.ListCustomers(ctx, ListCustomersRequest{Limit: 1})
+		return err
+	case "paypal":
+		gateway, err := NewPayPalGateway(config)
+		if err != nil {
+			return err
+		}
+		_, err = gateway.token(ctx)
+		return err
+	default:
+		return &UnsupportedProviderError{Provider: provider}
+	}
+}
@@ -0,0 +1,125 @@
+package customerbalance
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"apis/payments/services/stripe"
+)
+
+// Transaction types. Credits add to a balance; the rest move it as it is
+// spent, restored or reconciled.
+const (
+	TypeCredit     = "credit"     // A credit issued to the customer
+	TypeCharge     = "charge"     // Balance applied to a charge
+	TypeInvoice    = "invoice"    // Balance the provider applied to an invoice
+	TypeReversal   = "reversal"   // Balance restored when its charge failed
+	TypeAdjustment = "adjustment" // Balance matched to the provider's
+)
+
+// Reasons credits are issued for
+const (
+	ReasonGoodwill = "goodwill"
+	ReasonRefund   = "refund" // A charge refunded to the balance instead of the card
+	ReasonOther    = "other"
+)
+
+// DefaultHistoryLimit is how many transactions a balance is shown with
+// unless more are asked for, up to MaxHistoryLimit
+const (
+	DefaultHistoryLimit = 20
+	MaxHistoryLimit     = 100
+)
+
+var (
+	// ErrInvalidCredit is returned when issuing an invalid credit
+	ErrInvalidCredit = errors.New("invalid credit")
+	// ErrRefundExceedsCharge is returned when refunding more of a charge to
+	// the balance than is left to refund
+	ErrRefundExceedsCharge = errors.New("credit exceeds the charge's refundable amount")
+	// ErrInsufficientBalance is returned when spending more than the balance
+	ErrInsufficientBalance = errors.New("insufficient customer balance")
+)
+
+// Balance is a customer's credit in one currency, in minor units
+type Balance struct {
+	Currency  string    `json:"currency"`
+	Amount    int64     `json:"amount"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// Transaction is one movement of a customer's balance. Amount is positive
+// for credit added and negative for credit spent. SourceID is the charge or
+// invoice the balance was applied to, or the charge refunded to it.
+type Transaction struct {
+	ID                    string    `json:"id"`
+	TenantID              string    `json:"tenant_id"`
+	CustomerID            string    `json:"customer_id"`
+	Type                  string    `json:"type"`
+	Reason                string    `json:"reason,omitempty"`
+	Amount                int64     `json:"amount"`
+	Currency              string    `json:"currency"`
+	BalanceAfter          int64     `json:"balance_after"`
+	Description           string    `json:"description,omitempty"`
+	SourceID              string    `json:"source_id,omitempty"`
+	ProviderTransactionID string    `json:"provider_transaction_id,omitempty"`
+	CreatedBy             string    `json:"created_by,omitempty"`
+	CreatedAt             time.Time `json:"created_at"`
+}
+
+// CreditRequest issues a credit to a customer. Refunds to the balance name
+// the charge they refund.
+type CreditRequest struct {
+	Amount      int64  `json:"amount"`
+	Currency    string `json:"currency"`
+	Reason      string `json:"reason"`
+	Description string `json:"description,omitempty"`
+	ChargeID    string `json:"charge_id,omitempty"`
+	CreatedBy   string `json:"-"`
+}
+
+// Summary is a customer's balances with their latest transactions, newest
+// first
+type Summary struct {
+	CustomerID   string         `json:"customer_id"`
+	Balances     []*Balance     `json:"balances"`
+	Transactions []*Transaction `json:"transactions"`
+}
+
+// Application is the balance applied to a charge before it is created.
+// Remaining is left to charge the payment method; nothing is charged when
+// the balance covered the whole amount.
+type Application struct {
+	Applied     int64        `json:"balance_applied"`
+	Remaining   int64        `json:"amount_charged"`
+	Transaction *Transaction `json:"balance_transaction"`
+}
+
+// Covered reports whether the balance paid the whole charge
+func (a *Application) Covered() bool {
+	return a.Remaining == 0
+}
+
+// Reconciliation compares a customer's balance with the provider's, which
+// is held in one currency. Adjustment is set when the local balance was
+// changed to match.
+type Reconciliation struct {
+	CustomerID      string       `json:"customer_id"`
+	Currency        string       `json:"currency,omitempty"`
+	Balance         int64        `json:"balance"`
+	ProviderBalance int64        `json:"provider_balance"`
+	Adjustment      *Transaction `json:"adjustment,omitempty"`
+}
+
+// Provider holds the customer balance applied to invoices, in the
+// customer's currency
+type Provider interface {
+	GetCustomerBalance(ctx context.Context, customerID string) (*stripe.CustomerBalance, error)
+	AdjustCustomerBalance(ctx context.Context, customerID string, amount int64, currency, description string) (string, error)
+}
+
+// ChargeGetter retrieves the charges refunded to a balance
+type ChargeGetter interface {
+	GetCharge(ctx context.Context, chargeID string) (*stripe.Charge, error)
+}
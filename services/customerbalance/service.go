@@ -0,0 +1,415 @@
+package customerbalance
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"apis/payments/services/money"
+	"apis/payments/services/stripe"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Store persists customer balances and their transactions. Missing balances
+// and transactions return sql.ErrNoRows, as does a balance adjustment that
+// would leave the balance negative.
+type Store interface {
+	GetCustomerBalance(ctx context.Context, tenantID, customerID, currency string) (*Balance, error)
+	ListCustomerBalances(ctx context.Context, tenantID, customerID string) ([]*Balance, error)
+	AdjustCustomerBalance(ctx context.Context, tenantID, customerID, currency string, amount int64) (*Balance, error)
+	CreateCustomerBalanceTransaction(ctx context.Context, transaction *Transaction) (*Transaction, error)
+	ListCustomerBalanceTransactions(ctx context.Context, tenantID, customerID string, limit int) ([]*Transaction, error)
+	GetCustomerBalanceTransactionBySource(ctx context.Context, tenantID, transactionType, sourceID string) (*Transaction, error)
+	SumCustomerBalanceTransactionsBySource(ctx context.Context, tenantID, transactionType, sourceID string) (int64, error)
+	SetCustomerBalanceTransactionSource(ctx context.Context, id, sourceID string) error
+}
+
+// Service issues credits to customers and spends them on their charges and
+// invoices, keeping the balance in the customer's provider currency in step
+// with the provider's
+type Service struct {
+	store    Store
+	provider Provider
+	charges  ChargeGetter
+	tracer   trace.Tracer
+}
+
+// NewService creates a new customer balance service
+func NewService(store Store, provider Provider, charges ChargeGetter) *Service {
+	return &Service{
+		store:    store,
+		provider: provider,
+		charges:  charges,
+		tracer:   otel.Tracer("payments.customerbalance"),
+	}
+}
+
+// Summary returns a customer's balances and their latest transactions
+func (s *Service) Summary(ctx context.Context, tenantID, customerID string, limit int) (*Summary, error) {
+	ctx, span := s.tracer.Start(ctx, "Summary")
+	defer span.End()
+
+	if limit <= 0 {
+		limit = DefaultHistoryLimit
+	}
+	if limit > MaxHistoryLimit {
+		limit = MaxHistoryLimit
+	}
+
+	balances, err := s.store.ListCustomerBalances(ctx, tenantID, customerID)
+	if err != nil {
+		return nil, err
+	}
+	transactions, err := s.store.ListCustomerBalanceTransactions(ctx, tenantID, customerID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Summary{CustomerID: customerID, Balances: balances, Transactions: transactions}, nil
+}
+
+// Credit issues a credit to a customer. Credits in the currency the
+// provider holds the customer's balance in are added there first, so the
+// provider applies them to the customer's invoices.
+func (s *Service) Credit(ctx context.Context, tenantID, customerID string, request *CreditRequest) (*Transaction, error) {
+	ctx, span := s.tracer.Start(ctx, "Credit")
+	defer span.End()
+	span.SetAttributes(attribute.String("customer_id", customerID), attribute.String("reason", request.Reason))
+
+	currency := strings.ToLower(request.Currency)
+	if err := s.validateCredit(ctx, tenantID, customerID, currency, request); err != nil {
+		return nil, err
+	}
+
+	transaction := &Transaction{
+		TenantID:    tenantID,
+		CustomerID:  customerID,
+		Type:        TypeCredit,
+		Reason:      request.Reason,
+		Amount:      request.Amount,
+		Currency:    currency,
+		Description: request.Description,
+		SourceID:    request.ChargeID,
+		CreatedBy:   request.CreatedBy,
+	}
+
+	synced, err := s.providerSupports(ctx, customerID, currency)
+	if err != nil {
+		return nil, err
+	}
+	if synced {
+		// The provider counts credit as negative
+		transaction.ProviderTransactionID, err = s.provider.AdjustCustomerBalance(ctx, customerID, -request.Amount, currency, request.Description)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s.record(ctx, transaction)
+}
+
+// Apply spends a customer's balance in the charge's currency on a charge
+// before it is created, lowering the amount left to charge the payment
+// method. Enough is left to meet the currency's minimum unless the balance
+// covers the whole charge. Charges without a customer or balance return nil.
+func (s *Service) Apply(ctx context.Context, tenantID string, request *stripe.ChargeRequest) (*Application, error) {
+	ctx, span := s.tracer.Start(ctx, "Apply")
+	defer span.End()
+
+	if request.CustomerID == "" {
+		return nil, nil
+	}
+
+	amount, err := money.ResolveAmount(request.Amount, request.AmountDecimal, request.Currency)
+	if err != nil {
+		return nil, err
+	}
+	currency := strings.ToLower(request.Currency)
+
+	balance, err := s.store.GetCustomerBalance(ctx, tenantID, request.CustomerID, currency)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	applied := min(balance.Amount, amount)
+	if remaining := amount - applied; remaining > 0 && remaining < money.MinimumAmount(currency) {
+		applied = amount - money.MinimumAmount(currency)
+	}
+	if applied <= 0 {
+		return nil, nil
+	}
+
+	transaction := &Transaction{
+		TenantID:    tenantID,
+		CustomerID:  request.CustomerID,
+		Type:        TypeCharge,
+		Amount:      -applied,
+		Currency:    currency,
+		Description: request.Description,
+	}
+
+	// Balance spent here must leave the provider's too, or the provider
+	// would apply it to an invoice again
+	synced, err := s.providerSupports(ctx, request.CustomerID, currency)
+	if err != nil {
+		return nil, err
+	}
+	if synced {
+		transaction.ProviderTransactionID, err = s.provider.AdjustCustomerBalance(ctx, request.CustomerID, applied, currency, "Applied to a charge")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	transaction, err = s.record(ctx, transaction)
+	if err != nil {
+		if synced {
+			if _, undoErr := s.provider.AdjustCustomerBalance(ctx, request.CustomerID, -applied, currency, "Reversed a charge"); undoErr != nil {
+				log.Printf("Failed to restore customer %s provider balance of %d %s: %v", request.CustomerID, applied, currency, undoErr)
+			}
+		}
+		return nil, err
+	}
+
+	application := &Application{Applied: applied, Remaining: amount - applied, Transaction: transaction}
+	span.SetAttributes(attribute.Int64("applied", applied))
+
+	request.Amount = application.Remaining
+	request.AmountDecimal = ""
+	if request.Metadata == nil {
+		request.Metadata = make(map[string]string)
+	}
+	request.Metadata["balance_applied"] = strconv.FormatInt(applied, 10)
+	request.Metadata["balance_transaction"] = transaction.ID
+
+	return application, nil
+}
+
+// Settle links the balance applied to a charge with the charge created
+func (s *Service) Settle(ctx context.Context, application *Application, chargeID string) error {
+	ctx, span := s.tracer.Start(ctx, "Settle")
+	defer span.End()
+
+	if err := s.store.SetCustomerBalanceTransactionSource(ctx, application.Transaction.ID, chargeID); err != nil {
+		return err
+	}
+	application.Transaction.SourceID = chargeID
+	return nil
+}
+
+// Reverse restores the balance applied to a charge that was not created
+func (s *Service) Reverse(ctx context.Context, application *Application) (*Transaction, error) {
+	ctx, span := s.tracer.Start(ctx, "Reverse")
+	defer span.End()
+
+	return s.restore(ctx, application.Transaction)
+}
+
+// RecordInvoice records the balance the provider applied to an invoice when
+// it was finalized, from the customer's balance before and after. Balances
+// are counted the provider's way, with credit negative. Each invoice is
+// recorded once.
+func (s *Service) RecordInvoice(ctx context.Context, tenantID, customerID, invoiceID, currency string, startingBalance, endingBalance int64) error {
+	ctx, span := s.tracer.Start(ctx, "RecordInvoice")
+	defer span.End()
+	span.SetAttributes(attribute.String("invoice_id", invoiceID))
+
+	applied := endingBalance - startingBalance
+	if applied <= 0 {
+		return nil
+	}
+
+	_, err := s.store.GetCustomerBalanceTransactionBySource(ctx, tenantID, TypeInvoice, invoiceID)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	currency = strings.ToLower(currency)
+	balance, err := s.store.GetCustomerBalance(ctx, tenantID, customerID, currency)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// Credit added at the provider alone is left to reconciliation
+	applied = min(applied, balance.Amount)
+	if applied == 0 {
+		return nil
+	}
+
+	_, err = s.record(ctx, &Transaction{
+		TenantID:   tenantID,
+		CustomerID: customerID,
+		Type:       TypeInvoice,
+		Amount:     -applied,
+		Currency:   currency,
+		SourceID:   invoiceID,
+	})
+	return err
+}
+
+// Reconcile matches a customer's balance in their provider currency to the
+// provider's, which also changes when credit is added or applied to
+// invoices at the provider directly. Customers the provider holds no
+// balance for yet have nothing to reconcile.
+func (s *Service) Reconcile(ctx context.Context, tenantID, customerID string) (*Reconciliation, error) {
+	ctx, span := s.tracer.Start(ctx, "Reconcile")
+	defer span.End()
+
+	providerBalance, err := s.provider.GetCustomerBalance(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	reconciliation := &Reconciliation{CustomerID: customerID, Currency: providerBalance.Currency}
+	if providerBalance.Currency == "" {
+		return reconciliation, nil
+	}
+	// What the customer owes the provider is not credit
+	reconciliation.ProviderBalance = max(-providerBalance.Balance, 0)
+
+	balance, err := s.store.GetCustomerBalance(ctx, tenantID, customerID, providerBalance.Currency)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if balance != nil {
+		reconciliation.Balance = balance.Amount
+	}
+
+	difference := reconciliation.ProviderBalance - reconciliation.Balance
+	if difference == 0 {
+		return reconciliation, nil
+	}
+
+	log.Printf("Customer %s balance differs from the provider's by %d %s, adjusting", customerID, difference, providerBalance.Currency)
+	reconciliation.Adjustment, err = s.record(ctx, &Transaction{
+		TenantID:    tenantID,
+		CustomerID:  customerID,
+		Type:        TypeAdjustment,
+		Amount:      difference,
+		Currency:    providerBalance.Currency,
+		Description: "Reconciled with the provider balance",
+	})
+	if err != nil {
+		return nil, err
+	}
+	reconciliation.Balance = reconciliation.Adjustment.BalanceAfter
+
+	return reconciliation, nil
+}
+
+// validateCredit checks a credit, and that refunds to the balance leave no
+// more of the charge refunded than was captured
+func (s *Service) validateCredit(ctx context.Context, tenantID, customerID, currency string, request *CreditRequest) error {
+	switch {
+	case request.Amount <= 0:
+		return fmt.Errorf("%w: amount must be positive", ErrInvalidCredit)
+	case !money.ValidCurrency(currency):
+		return fmt.Errorf("%w: currency must be a three-letter code", ErrInvalidCredit)
+	case request.Reason != ReasonGoodwill && request.Reason != ReasonRefund && request.Reason != ReasonOther:
+		return fmt.Errorf("%w: reason must be %s, %s or %s", ErrInvalidCredit, ReasonGoodwill, ReasonRefund, ReasonOther)
+	case len(request.Description) > 500:
+		return fmt.Errorf("%w: description must be at most 500 characters", ErrInvalidCredit)
+	case request.Reason == ReasonRefund && request.ChargeID == "":
+		return fmt.Errorf("%w: charge_id is required for refunds", ErrInvalidCredit)
+	case request.ChargeID == "":
+		return nil
+	}
+
+	charge, err := s.charges.GetCharge(ctx, request.ChargeID)
+	if err != nil {
+		return err
+	}
+	if charge.CustomerID != customerID || !strings.EqualFold(charge.Currency, currency) {
+		return fmt.Errorf("%w: charge %s is not a %s charge of the customer", ErrInvalidCredit, request.ChargeID, currency)
+	}
+	if request.Reason != ReasonRefund {
+		return nil
+	}
+	if charge.Status != "succeeded" {
+		return fmt.Errorf("%w: charge %s has not succeeded", ErrInvalidCredit, request.ChargeID)
+	}
+
+	credited, err := s.store.SumCustomerBalanceTransactionsBySource(ctx, tenantID, TypeCredit, request.ChargeID)
+	if err != nil {
+		return err
+	}
+	captured := charge.Amount
+	if charge.AmountCaptured > 0 {
+		captured = charge.AmountCaptured
+	}
+	if refundable := captured - charge.AmountRefunded - credited; request.Amount > refundable {
+		return fmt.Errorf("%w: %d of charge %s is left to refund", ErrRefundExceedsCharge, max(refundable, 0), request.ChargeID)
+	}
+	return nil
+}
+
+// providerSupports reports whether the provider holds the customer's
+// balance in a currency. It takes the currency of the first credit.
+func (s *Service) providerSupports(ctx context.Context, customerID, currency string) (bool, error) {
+	balance, err := s.provider.GetCustomerBalance(ctx, customerID)
+	if err != nil {
+		return false, err
+	}
+	return balance.Currency == "" || balance.Currency == currency, nil
+}
+
+// restore credits back a balance transaction that spent the balance, at the
+// provider too when it was synced there
+func (s *Service) restore(ctx context.Context, spent *Transaction) (*Transaction, error) {
+	reversal := &Transaction{
+		TenantID:   spent.TenantID,
+		CustomerID: spent.CustomerID,
+		Type:       TypeReversal,
+		Amount:     -spent.Amount,
+		Currency:   spent.Currency,
+		SourceID:   spent.ID,
+	}
+	if spent.ProviderTransactionID != "" {
+		providerID, err := s.provider.AdjustCustomerBalance(ctx, spent.CustomerID, spent.Amount, spent.Currency, "Reversed a charge")
+		if err != nil {
+			return nil, err
+		}
+		reversal.ProviderTransactionID = providerID
+	}
+	return s.record(ctx, reversal)
+}
+
+// record moves a customer's balance and stores the transaction. Moves that
+// would leave the balance negative return ErrInsufficientBalance.
+func (s *Service) record(ctx context.Context, transaction *Transaction) (*Transaction, error) {
+	balance, err := s.store.AdjustCustomerBalance(ctx, transaction.TenantID, transaction.CustomerID, transaction.Currency, transaction.Amount)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInsufficientBalance
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	transaction.ID = "cbtxn_" + uuid.New().String()
+	transaction.BalanceAfter = balance.Amount
+	created, err := s.store.CreateCustomerBalanceTransaction(ctx, transaction)
+	if err != nil {
+		// Undo the move so the balance still matches its transactions
+		if _, undoErr := s.store.AdjustCustomerBalance(ctx, transaction.TenantID, transaction.CustomerID, transaction.Currency, -transaction.Amount); undoErr != nil {
+			log.Printf("Failed to undo customer %s balance move of %d %s: %v", transaction.CustomerID, transaction.Amount, transaction.Currency, undoErr)
+		}
+		return nil, err
+	}
+	return created, nil
+}
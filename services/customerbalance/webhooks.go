@@ -0,0 +1,29 @@
+package customerbalance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"apis/payments/services/stripe"
+	"apis/payments/services/tenancy"
+
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// RegisterWebhookHandlers records the balance Stripe applies to invoices as
+// they are finalized
+func (s *Service) RegisterWebhookHandlers(webhooks *stripe.WebhookService) {
+	webhooks.On(stripego.EventTypeInvoiceFinalized, func(ctx context.Context, event stripego.Event) error {
+		var invoice stripego.Invoice
+		if err := json.Unmarshal(event.Data.Raw, &invoice); err != nil {
+			return fmt.Errorf("failed to parse invoice: %w", err)
+		}
+		if invoice.Customer == nil || invoice.StartingBalance == invoice.EndingBalance {
+			return nil
+		}
+
+		return s.RecordInvoice(ctx, tenancy.ID(ctx), invoice.Customer.ID, invoice.ID,
+			string(invoice.Currency), invoice.StartingBalance, invoice.EndingBalance)
+	})
+}
@@ -0,0 +1,187 @@
+package customers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	// ErrDuplicateCustomer is matched by DuplicateError
+	ErrDuplicateCustomer = errors.New("a customer with this email and name already exists")
+	// ErrInvalidVerificationToken is returned for unknown or expired email verification tokens
+	ErrInvalidVerificationToken = errors.New("invalid or expired email verification token")
+	// ErrAlreadyVerified is returned when requesting verification of a verified email
+	ErrAlreadyVerified = errors.New("customer email is already verified")
+	// ErrIdentityNotFound is returned for customers created before identities were recorded
+	ErrIdentityNotFound = errors.New("customer identity not found")
+)
+
+// Config controls duplicate detection, email verification and how long
+// deleted customers are retained
+type Config struct {
+	// NameSimilarity is the minimum similarity (0 to 1) of two normalized
+	// names sharing an email for them to be treated as the same customer
+	NameSimilarity float64
+	// VerificationEnabled issues an email verification token for each new customer
+	VerificationEnabled bool
+	// VerificationTTL is how long verification tokens remain valid
+	VerificationTTL time.Duration
+	// RetentionPeriod is how long deleted customers are kept, and can be
+	// restored, before they are deleted at the provider. Zero deletes them
+	// immediately.
+	RetentionPeriod time.Duration
+	// PurgeInterval is how often customers past their retention period are purged
+	PurgeInterval time.Duration
+}
+
+// LoadConfig loads the customer identity configuration from environment variables
+func LoadConfig() *Config {
+	config := &Config{
+		NameSimilarity:  0.85,
+		VerificationTTL: 48 * time.Hour,
+		RetentionPeriod: 30 * 24 * time.Hour,
+		PurgeInterval:   time.Hour,
+	}
+
+	if value, err := strconv.ParseFloat(os.Getenv("CUSTOMER_DUPLICATE_NAME_SIMILARITY"), 64); err == nil && value > 0 && value <= 1 {
+		config.NameSimilarity = value
+	}
+	if enabled, err := strconv.ParseBool(os.Getenv("CUSTOMER_EMAIL_VERIFICATION")); err == nil {
+		config.VerificationEnabled = enabled
+	}
+	if hours, err := strconv.Atoi(os.Getenv("CUSTOMER_EMAIL_VERIFICATION_TTL_HOURS")); err == nil && hours > 0 {
+		config.VerificationTTL = time.Duration(hours) * time.Hour
+	}
+	if days, err := strconv.Atoi(os.Getenv("CUSTOMER_RETENTION_DAYS")); err == nil && days >= 0 {
+		config.RetentionPeriod = time.Duration(days) * 24 * time.Hour
+	}
+	if minutes, err := strconv.Atoi(os.Getenv("CUSTOMER_PURGE_INTERVAL_MINUTES")); err == nil && minutes > 0 {
+		config.PurgeInterval = time.Duration(minutes) * time.Minute
+	}
+
+	return config
+}
+
+// Identity records who a customer is within a tenant
+type Identity struct {
+	CustomerID            string     `json:"customer_id"`
+	TenantID              string     `json:"tenant_id"`
+	Email                 string     `json:"email"` // Normalized
+	Name                  string     `json:"name"`
+	EmailVerified         bool       `json:"email_verified"`
+	EmailVerifiedAt       *time.Time `json:"email_verified_at,omitempty"`
+	VerificationTokenHash string     `json:"-"`
+	VerificationExpiresAt *time.Time `json:"-"`
+	CreatedAt             time.Time  `json:"created_at"`
+}
+
+// DuplicateError references the existing customer a creation duplicates
+type DuplicateError struct {
+	Existing   *Identity
+	Similarity float64
+}
+
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrDuplicateCustomer, e.Existing.CustomerID)
+}
+
+// Is matches ErrDuplicateCustomer
+func (e *DuplicateError) Is(target error) bool {
+	return target == ErrDuplicateCustomer
+}
+
+// Verification is a newly issued email verification token. The token is
+// only available when issued; it is delivered to the customer out of band.
+type Verification struct {
+	CustomerID string    `json:"customer_id"`
+	Email      string    `json:"email"`
+	Token      string    `json:"token"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// Store persists customer identities
+type Store interface {
+	CreateCustomerIdentity(ctx context.Context, identity *Identity) (*Identity, error)
+	GetCustomerIdentity(ctx context.Context, customerID string) (*Identity, error)
+	ListCustomerIdentitiesByEmail(ctx context.Context, tenantID, email string) ([]*Identity, error)
+	UpdateCustomerIdentity(ctx context.Context, customerID, email, name string, verifiedAt *time.Time) (*Identity, error)
+	SetCustomerVerificationToken(ctx context.Context, customerID, tokenHash string, expiresAt time.Time) error
+	MarkCustomerEmailVerified(ctx context.Context, customerID string, verifiedAt time.Time) (*Identity, error)
+	DeleteCustomerIdentity(ctx context.Context, customerID string) error
+}
+
+// NormalizeEmail reduces an email to the mailbox it delivers to: lowercased,
+// without a +tag, and for Gmail without dots in the local part
+func NormalizeEmail(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return email
+	}
+
+	local, domain := email[:at], email[at+1:]
+	if plus := strings.Index(local, "+"); plus >= 0 {
+		local = local[:plus]
+	}
+	if domain == "gmail.com" || domain == "googlemail.com" {
+		local = strings.ReplaceAll(local, ".", "")
+		domain = "gmail.com"
+	}
+
+	return local + "@" + domain
+}
+
+// NormalizeName lowercases a name, drops punctuation and sorts its words so
+// "Doe, Jane" and "jane doe" compare equal
+func NormalizeName(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	sort.Strings(words)
+	return strings.Join(words, " ")
+}
+
+// NameSimilarity returns how alike two names are, from 0 to 1, as one minus
+// the edit distance between their normalized forms over the longer length
+func NameSimilarity(a, b string) float64 {
+	x, y := []rune(NormalizeName(a)), []rune(NormalizeName(b))
+	longest := len(x)
+	if len(y) > longest {
+		longest = len(y)
+	}
+	if longest == 0 {
+		return 1
+	}
+
+	return 1 - float64(editDistance(x, y))/float64(longest)
+}
+
+// editDistance returns the Levenshtein distance between two rune slices
+func editDistance(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
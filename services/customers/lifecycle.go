@@ -0,0 +1,294 @@
+package customers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"apis/payments/services/events"
+	"apis/payments/services/stripe"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// EventErased is emitted once a customer's personal data has been erased,
+// as the audit record of the erasure
+const EventErased = "payments.customer.erased"
+
+// purgeBatchSize is the number of due deletions purged per query
+const purgeBatchSize = 100
+
+var (
+	// ErrCustomerDeleted is returned for customers deleted within their retention period
+	ErrCustomerDeleted = errors.New("customer is deleted")
+	// ErrCustomerErased is returned for customers whose personal data has been erased
+	ErrCustomerErased = errors.New("customer's personal data has been erased")
+	// ErrNotRestorable is returned when restoring a customer that isn't deleted or was already purged
+	ErrNotRestorable = errors.New("customer is not deleted or has already been purged")
+)
+
+// Deletion is a deleted customer, kept until PurgeAfter and then deleted at
+// the provider
+type Deletion struct {
+	CustomerID string     `json:"customer_id"`
+	TenantID   string     `json:"tenant_id"`
+	DeletedAt  time.Time  `json:"deleted_at"`
+	PurgeAfter time.Time  `json:"purge_after"`
+	PurgedAt   *time.Time `json:"purged_at,omitempty"`
+}
+
+// Erasure records that a customer's personal data was erased
+type Erasure struct {
+	CustomerID             string    `json:"customer_id"`
+	TenantID               string    `json:"tenant_id"`
+	ErasedAt               time.Time `json:"erased_at"`
+	PaymentMethodsDetached int       `json:"payment_methods_detached"`
+}
+
+// LifecycleStore persists customer deletions and erasures.
+// ClaimCustomerDeletion returns sql.ErrNoRows once a deletion is purged or
+// claimed, and EraseCustomer anonymizes the customer's email, name and phone
+// everywhere they are stored, returning the first erasure when repeated.
+type LifecycleStore interface {
+	CreateCustomerDeletion(ctx context.Context, deletion *Deletion) (*Deletion, error)
+	GetCustomerDeletion(ctx context.Context, customerID string) (*Deletion, error)
+	DeleteCustomerDeletion(ctx context.Context, customerID string) (bool, error)
+	ListDueCustomerDeletions(ctx context.Context, now time.Time, limit int) ([]*Deletion, error)
+	ClaimCustomerDeletion(ctx context.Context, customerID string, purgedAt time.Time) (*Deletion, error)
+	ReleaseCustomerDeletion(ctx context.Context, customerID string) error
+	EraseCustomer(ctx context.Context, erasure *Erasure) (*Erasure, error)
+	GetCustomerErasure(ctx context.Context, customerID string) (*Erasure, error)
+	DeleteCustomerIdentity(ctx context.Context, customerID string) error
+	DeleteCustomerReferences(ctx context.Context, customerID string) error
+}
+
+// LifecycleProvider looks up and deletes provider customers and detaches
+// their payment methods
+type LifecycleProvider interface {
+	GetCustomer(ctx context.Context, customerID string) (*stripe.Customer, error)
+	DeleteCustomer(ctx context.Context, customerID string) error
+	ListPaymentMethods(ctx context.Context, customerID string, page stripe.Page) ([]*stripe.PaymentMethod, error)
+	DetachPaymentMethod(ctx context.Context, paymentMethodID string) error
+}
+
+// Lifecycle soft-deletes customers, purging them at the provider once their
+// retention period ends, and erases customers' personal data on request
+type Lifecycle struct {
+	store    LifecycleStore
+	provider LifecycleProvider
+	emitter  *events.Emitter
+	config   *Config
+	tracer   trace.Tracer
+}
+
+// NewLifecycle creates a new customer lifecycle service
+func NewLifecycle(store LifecycleStore, provider LifecycleProvider, emitter *events.Emitter, config *Config) *Lifecycle {
+	return &Lifecycle{
+		store:    store,
+		provider: provider,
+		emitter:  emitter,
+		config:   config,
+		tracer:   otel.Tracer("payments.customers"),
+	}
+}
+
+// Delete soft-deletes a customer until its retention period ends. Deleting
+// a deleted customer returns its deletion unchanged. Without a retention
+// period the customer is purged immediately.
+func (l *Lifecycle) Delete(ctx context.Context, tenantID, customerID string) (*Deletion, error) {
+	ctx, span := l.tracer.Start(ctx, "Delete")
+	defer span.End()
+
+	now := time.Now()
+	if l.config.RetentionPeriod <= 0 {
+		if err := l.purge(ctx, customerID); err != nil {
+			return nil, err
+		}
+		return &Deletion{CustomerID: customerID, TenantID: tenantID, DeletedAt: now, PurgeAfter: now, PurgedAt: &now}, nil
+	}
+
+	if _, err := l.provider.GetCustomer(ctx, customerID); err != nil {
+		return nil, err
+	}
+
+	return l.store.CreateCustomerDeletion(ctx, &Deletion{
+		CustomerID: customerID,
+		TenantID:   tenantID,
+		DeletedAt:  now,
+		PurgeAfter: now.Add(l.config.RetentionPeriod),
+	})
+}
+
+// Restore undeletes a customer within its retention period
+func (l *Lifecycle) Restore(ctx context.Context, customerID string) error {
+	ctx, span := l.tracer.Start(ctx, "Restore")
+	defer span.End()
+
+	restored, err := l.store.DeleteCustomerDeletion(ctx, customerID)
+	if err != nil {
+		return err
+	}
+	if !restored {
+		return ErrNotRestorable
+	}
+
+	return nil
+}
+
+// Check returns ErrCustomerDeleted or ErrCustomerErased for customers that
+// are no longer served
+func (l *Lifecycle) Check(ctx context.Context, customerID string) error {
+	ctx, span := l.tracer.Start(ctx, "Check")
+	defer span.End()
+
+	if _, err := l.store.GetCustomerErasure(ctx, customerID); err == nil {
+		return ErrCustomerErased
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	if _, err := l.store.GetCustomerDeletion(ctx, customerID); err == nil {
+		return ErrCustomerDeleted
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	return nil
+}
+
+// Erase anonymizes a customer's email, name and phone locally while keeping
+// its charges and refunds, detaches its payment methods at the provider and
+// emits EventErased. Erasing again retries anything left undone.
+func (l *Lifecycle) Erase(ctx context.Context, tenantID, customerID string) (*Erasure, error) {
+	ctx, span := l.tracer.Start(ctx, "Erase")
+	defer span.End()
+
+	// Purged customers are gone from the provider along with their payment methods
+	deletion, err := l.store.GetCustomerDeletion(ctx, customerID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	purged := deletion != nil && deletion.PurgedAt != nil
+	if !purged {
+		if _, err := l.provider.GetCustomer(ctx, customerID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Erased locally first, so webhooks for the detachments can't bring the data back
+	erasure, err := l.store.EraseCustomer(ctx, &Erasure{CustomerID: customerID, TenantID: tenantID, ErasedAt: time.Now()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to erase customer: %w", err)
+	}
+
+	if !purged {
+		paymentMethods, err := l.provider.ListPaymentMethods(ctx, customerID, stripe.Page{})
+		if err != nil {
+			return nil, err
+		}
+		for _, paymentMethod := range paymentMethods {
+			if err := l.provider.DetachPaymentMethod(ctx, paymentMethod.ID); err != nil {
+				return nil, fmt.Errorf("failed to detach payment method %s: %w", paymentMethod.ID, err)
+			}
+			erasure.PaymentMethodsDetached++
+		}
+	}
+
+	if l.emitter != nil {
+		if err := l.emitter.Emit(ctx, "customers", EventErased, customerID, erasure); err != nil {
+			log.Printf("Failed to emit erasure of customer %s: %v", customerID, err)
+		}
+	}
+
+	return erasure, nil
+}
+
+// PurgeDue deletes customers past their retention period at the provider,
+// returning how many were purged. A customer that fails to purge is left
+// for the next run.
+func (l *Lifecycle) PurgeDue(ctx context.Context, now time.Time) (int, error) {
+	ctx, span := l.tracer.Start(ctx, "PurgeDue")
+	defer span.End()
+
+	purged := 0
+	for {
+		due, err := l.store.ListDueCustomerDeletions(ctx, now, purgeBatchSize)
+		if err != nil {
+			return purged, err
+		}
+
+		failed := 0
+		for _, deletion := range due {
+			// Claimed first, so replicas don't purge the same customer
+			if _, err := l.store.ClaimCustomerDeletion(ctx, deletion.CustomerID, now); errors.Is(err, sql.ErrNoRows) {
+				continue
+			} else if err != nil {
+				return purged, err
+			}
+
+			if err := l.purge(ctx, deletion.CustomerID); err != nil {
+				log.Printf("Failed to purge customer %s: %v", deletion.CustomerID, err)
+				if err := l.store.ReleaseCustomerDeletion(ctx, deletion.CustomerID); err != nil {
+					return purged, err
+				}
+				failed++
+				continue
+			}
+			purged++
+		}
+
+		if len(due) < purgeBatchSize || failed == len(due) {
+			return purged, nil
+		}
+	}
+}
+
+// Start purges customers past their retention period every PurgeInterval
+// until the returned stop function is called
+func (l *Lifecycle) Start() (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(l.config.PurgeInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := l.PurgeDue(context.Background(), time.Now()); err != nil {
+					log.Printf("Customer purge run failed: %v", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// purge deletes a customer at the provider and forgets its identity and
+// external references
+func (l *Lifecycle) purge(ctx context.Context, customerID string) error {
+	if err := l.provider.DeleteCustomer(ctx, customerID); err != nil {
+		return err
+	}
+
+	if err := l.store.DeleteCustomerIdentity(ctx, customerID); err != nil {
+		log.Printf("Failed to remove identity for customer %s: %v", customerID, err)
+	}
+	if err := l.store.DeleteCustomerReferences(ctx, customerID); err != nil {
+		log.Printf("Failed to remove references for customer %s: %v", customerID, err)
+	}
+
+	return nil
+}
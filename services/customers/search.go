@@ -0,0 +1,101 @@
+package customers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"apis/payments/services/stripe"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Typeahead searches return DefaultSearchResults customers unless asked
+// for more, up to MaxSearchResults
+const (
+	DefaultSearchResults = 10
+	MaxSearchResults     = 25
+)
+
+var (
+	// ErrInvalidCreatedRange is returned when created_after is not before created_before
+	ErrInvalidCreatedRange = errors.New("created_after must be before created_before")
+	// ErrSearchQueryRequired is returned for typeahead searches without a query
+	ErrSearchQueryRequired = errors.New("q is required")
+)
+
+// Filter narrows a customer listing. Email matches case-insensitively and
+// NamePrefix matches the start of the name; customers must hold every
+// Metadata pair.
+type Filter struct {
+	TenantID      string
+	Email         string
+	NamePrefix    string
+	Metadata      map[string]string
+	CreatedAfter  *time.Time // Inclusive
+	CreatedBefore *time.Time // Exclusive
+	StartingAfter string     // ID of the last customer of the previous page
+	Limit         int
+}
+
+// SearchStore finds mirrored customers. ListCustomers returns customers
+// newest first; SearchCustomers matches a customer ID exactly, or the start
+// of an email or name compared lowercased. Emails and prefixes are passed
+// lowercased, and prefixes with LIKE wildcards escaped.
+type SearchStore interface {
+	ListCustomers(ctx context.Context, filter Filter) ([]*stripe.Customer, error)
+	SearchCustomers(ctx context.Context, tenantID, customerID, prefix string, limit int) ([]*stripe.Customer, error)
+}
+
+// Searcher lists and searches the customers mirrored from the provider
+type Searcher struct {
+	store  SearchStore
+	tracer trace.Tracer
+}
+
+// NewSearcher creates a new customer searcher
+func NewSearcher(store SearchStore) *Searcher {
+	return &Searcher{
+		store:  store,
+		tracer: otel.Tracer("payments.customers"),
+	}
+}
+
+// List lists a tenant's customers matching a filter, newest first
+func (s *Searcher) List(ctx context.Context, filter Filter) ([]*stripe.Customer, error) {
+	ctx, span := s.tracer.Start(ctx, "List")
+	defer span.End()
+
+	if filter.CreatedAfter != nil && filter.CreatedBefore != nil && !filter.CreatedAfter.Before(*filter.CreatedBefore) {
+		return nil, ErrInvalidCreatedRange
+	}
+
+	filter.Email = strings.ToLower(strings.TrimSpace(filter.Email))
+	filter.NamePrefix = escapeLike(strings.ToLower(strings.TrimSpace(filter.NamePrefix)))
+	return s.store.ListCustomers(ctx, filter)
+}
+
+// Search finds a tenant's customers for typeahead: the customer with the
+// query as its ID, then those whose email or name starts with it, by name
+func (s *Searcher) Search(ctx context.Context, tenantID, query string, limit int) ([]*stripe.Customer, error) {
+	ctx, span := s.tracer.Start(ctx, "Search")
+	defer span.End()
+
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, ErrSearchQueryRequired
+	}
+	if limit <= 0 {
+		limit = DefaultSearchResults
+	}
+	limit = min(limit, MaxSearchResults)
+
+	return s.store.SearchCustomers(ctx, tenantID, query, escapeLike(strings.ToLower(query)), limit)
+}
+
+// escapeLike escapes the LIKE wildcards in a value so it only matches itself
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
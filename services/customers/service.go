@@ -0,0 +1,225 @@
+package customers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"apis/payments/services/events"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// EventVerificationRequested is emitted with a new verification token so a
+// notifier can email it to the customer
+const EventVerificationRequested = "payments.customer.email_verification_requested"
+
+// Service detects duplicate customers and verifies customer emails
+type Service struct {
+	store   Store
+	emitter *events.Emitter
+	config  *Config
+	tracer  trace.Tracer
+}
+
+// NewService creates a new customer identity service
+func NewService(store Store, emitter *events.Emitter, config *Config) *Service {
+	return &Service{
+		store:   store,
+		emitter: emitter,
+		config:  config,
+		tracer:  otel.Tracer("payments.customers"),
+	}
+}
+
+// VerificationEnabled reports whether new customers are sent a verification token
+func (s *Service) VerificationEnabled() bool {
+	return s.config.VerificationEnabled
+}
+
+// CheckDuplicate returns a *DuplicateError if the tenant already has a
+// customer with the same normalized email and a similar name. The check runs
+// before the provider customer is created, so concurrent creations of the
+// same customer may both pass.
+func (s *Service) CheckDuplicate(ctx context.Context, tenantID, email, name string) error {
+	ctx, span := s.tracer.Start(ctx, "CheckDuplicate")
+	defer span.End()
+
+	existing, err := s.store.ListCustomerIdentitiesByEmail(ctx, tenantID, NormalizeEmail(email))
+	if err != nil {
+		return err
+	}
+
+	var best *DuplicateError
+	for _, identity := range existing {
+		similarity := NameSimilarity(identity.Name, name)
+		if similarity < s.config.NameSimilarity {
+			continue
+		}
+		if best == nil || similarity > best.Similarity {
+			best = &DuplicateError{Existing: identity, Similarity: similarity}
+		}
+	}
+	if best != nil {
+		return best
+	}
+
+	return nil
+}
+
+// Register records the identity of a newly created customer and, when
+// verification is enabled, issues a verification token for its email
+func (s *Service) Register(ctx context.Context, tenantID, customerID, email, name string) (*Identity, error) {
+	ctx, span := s.tracer.Start(ctx, "Register")
+	defer span.End()
+
+	identity, err := s.store.CreateCustomerIdentity(ctx, &Identity{
+		CustomerID: customerID,
+		TenantID:   tenantID,
+		Email:      NormalizeEmail(email),
+		Name:       name,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.config.VerificationEnabled {
+		if _, err := s.issueVerification(ctx, identity, email); err != nil {
+			return nil, err
+		}
+	}
+
+	return identity, nil
+}
+
+// Get returns a customer's identity
+func (s *Service) Get(ctx context.Context, customerID string) (*Identity, error) {
+	ctx, span := s.tracer.Start(ctx, "Get")
+	defer span.End()
+
+	identity, err := s.store.GetCustomerIdentity(ctx, customerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrIdentityNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return identity, nil
+}
+
+// Update records a customer's new email and name. Changing the email clears
+// its verification.
+func (s *Service) Update(ctx context.Context, customerID, email, name string) (*Identity, error) {
+	ctx, span := s.tracer.Start(ctx, "Update")
+	defer span.End()
+
+	identity, err := s.Get(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	normalized := NormalizeEmail(email)
+	verifiedAt := identity.EmailVerifiedAt
+	if normalized != identity.Email {
+		verifiedAt = nil
+	}
+
+	return s.store.UpdateCustomerIdentity(ctx, customerID, normalized, name, verifiedAt)
+}
+
+// Forget removes a deleted customer's identity
+func (s *Service) Forget(ctx context.Context, customerID string) error {
+	ctx, span := s.tracer.Start(ctx, "Forget")
+	defer span.End()
+
+	return s.store.DeleteCustomerIdentity(ctx, customerID)
+}
+
+// RequestVerification issues a new verification token for a customer's
+// email, replacing any outstanding one
+func (s *Service) RequestVerification(ctx context.Context, customerID, email string) (*Verification, error) {
+	ctx, span := s.tracer.Start(ctx, "RequestVerification")
+	defer span.End()
+
+	identity, err := s.Get(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if identity.EmailVerified {
+		return nil, ErrAlreadyVerified
+	}
+
+	return s.issueVerification(ctx, identity, email)
+}
+
+// VerifyEmail marks a customer's email verified if the token matches the
+// outstanding one and has not expired
+func (s *Service) VerifyEmail(ctx context.Context, customerID, token string) (*Identity, error) {
+	ctx, span := s.tracer.Start(ctx, "VerifyEmail")
+	defer span.End()
+
+	identity, err := s.Get(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if identity.EmailVerified {
+		return identity, nil
+	}
+
+	now := time.Now()
+	if identity.VerificationTokenHash == "" || identity.VerificationTokenHash != hashToken(token) ||
+		identity.VerificationExpiresAt == nil || !now.Before(*identity.VerificationExpiresAt) {
+		return nil, ErrInvalidVerificationToken
+	}
+
+	return s.store.MarkCustomerEmailVerified(ctx, customerID, now)
+}
+
+// issueVerification stores a new token's hash and emits the token for delivery
+func (s *Service) issueVerification(ctx context.Context, identity *Identity, email string) (*Verification, error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	verification := &Verification{
+		CustomerID: identity.CustomerID,
+		Email:      email,
+		Token:      token,
+		ExpiresAt:  time.Now().Add(s.config.VerificationTTL),
+	}
+	if err := s.store.SetCustomerVerificationToken(ctx, identity.CustomerID, hashToken(token), verification.ExpiresAt); err != nil {
+		return nil, err
+	}
+
+	if s.emitter != nil {
+		if err := s.emitter.Emit(ctx, "customers", EventVerificationRequested, identity.CustomerID, verification); err != nil {
+			log.Printf("Failed to emit email verification for customer %s: %v", identity.CustomerID, err)
+		}
+	}
+
+	return verification, nil
+}
+
+// hashToken hashes a verification token for storage
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// newToken generates a random verification token
+func newToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate verification token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,176 @@
+package customers
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"apis/payments/services/stripe"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ExternalReferenceMetadataKey holds a customer's external reference in provider metadata
+const ExternalReferenceMetadataKey = "external_reference"
+
+// ErrInvalidExternalReference is returned for missing or overlong external references
+var ErrInvalidExternalReference = errors.New("external_reference is required and must be at most 255 characters")
+
+// Reference maps a caller's own ID for a customer, such as its user ID, to
+// the provider customer
+type Reference struct {
+	TenantID          string    `json:"tenant_id"`
+	ExternalReference string    `json:"external_reference"`
+	CustomerID        string    `json:"customer_id"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// ReferenceStore persists external references. CreateCustomerReference
+// returns sql.ErrNoRows when the tenant already maps the reference.
+type ReferenceStore interface {
+	CreateCustomerReference(ctx context.Context, reference *Reference) (*Reference, error)
+	GetCustomerReference(ctx context.Context, tenantID, externalReference string) (*Reference, error)
+	DeleteCustomerReferences(ctx context.Context, customerID string) error
+}
+
+// Provider creates, updates and deletes provider customers
+type Provider interface {
+	CreateCustomer(ctx context.Context, request *stripe.CustomerRequest) (*stripe.Customer, error)
+	UpdateCustomer(ctx context.Context, customerID string, request *stripe.CustomerRequest) (*stripe.Customer, error)
+	DeleteCustomer(ctx context.Context, customerID string) error
+}
+
+// Upserter creates or updates customers keyed by an external reference.
+// Racing upserts of the same reference converge on one customer: creations
+// share a provider idempotency key, and the reference mapping's unique key
+// decides the winner if they still create different customers.
+type Upserter struct {
+	store      ReferenceStore
+	provider   Provider
+	identities *Service
+	tracer     trace.Tracer
+}
+
+// NewUpserter creates a new customer upserter that records the identities of
+// the customers it creates
+func NewUpserter(store ReferenceStore, provider Provider, identities *Service) *Upserter {
+	return &Upserter{
+		store:      store,
+		provider:   provider,
+		identities: identities,
+		tracer:     otel.Tracer("payments.customers"),
+	}
+}
+
+// Upsert updates the tenant's customer with the external reference, creating
+// it if there is none. It reports whether the customer was created.
+func (u *Upserter) Upsert(ctx context.Context, tenantID, externalReference string, request *stripe.CustomerRequest) (*stripe.Customer, bool, error) {
+	ctx, span := u.tracer.Start(ctx, "Upsert")
+	defer span.End()
+
+	if externalReference == "" || len(externalReference) > 255 {
+		return nil, false, ErrInvalidExternalReference
+	}
+
+	// The reference travels with the provider customer
+	metadata := make(map[string]string, len(request.Metadata)+1)
+	for key, value := range request.Metadata {
+		metadata[key] = value
+	}
+	metadata[ExternalReferenceMetadataKey] = externalReference
+	request.Metadata = metadata
+
+	existing, err := u.store.GetCustomerReference(ctx, tenantID, externalReference)
+	if err == nil {
+		return u.update(ctx, existing.CustomerID, request)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, false, fmt.Errorf("failed to get customer reference: %w", err)
+	}
+
+	request.IdempotencyKey = upsertIdempotencyKey(tenantID, externalReference)
+	customer, createErr := u.provider.CreateCustomer(ctx, request)
+	if createErr != nil {
+		// A racing upsert with a different body reuses the idempotency key
+		// and is rejected; it updates the winner's customer instead
+		if existing, err := u.store.GetCustomerReference(ctx, tenantID, externalReference); err == nil {
+			return u.update(ctx, existing.CustomerID, request)
+		}
+		return nil, false, createErr
+	}
+
+	_, err = u.store.CreateCustomerReference(ctx, &Reference{
+		TenantID:          tenantID,
+		ExternalReference: externalReference,
+		CustomerID:        customer.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return u.resolveRace(ctx, tenantID, externalReference, customer, request)
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create customer reference: %w", err)
+	}
+
+	if _, err := u.identities.Register(ctx, tenantID, customer.ID, customer.Email, customer.Name); err != nil {
+		log.Printf("Failed to record identity for customer %s: %v", customer.ID, err)
+	}
+
+	return customer, true, nil
+}
+
+// Forget removes a deleted customer's external references
+func (u *Upserter) Forget(ctx context.Context, customerID string) error {
+	ctx, span := u.tracer.Start(ctx, "Forget")
+	defer span.End()
+
+	return u.store.DeleteCustomerReferences(ctx, customerID)
+}
+
+// resolveRace handles losing the reference to a concurrent upsert. The
+// idempotency key usually made both create the same customer; otherwise the
+// customer created here is removed and the winner's is updated.
+func (u *Upserter) resolveRace(ctx context.Context, tenantID, externalReference string, customer *stripe.Customer, request *stripe.CustomerRequest) (*stripe.Customer, bool, error) {
+	winner, err := u.store.GetCustomerReference(ctx, tenantID, externalReference)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get customer reference: %w", err)
+	}
+	if winner.CustomerID == customer.ID {
+		return customer, false, nil
+	}
+
+	if err := u.provider.DeleteCustomer(ctx, customer.ID); err != nil {
+		log.Printf("Failed to delete customer %s duplicating %s: %v", customer.ID, winner.CustomerID, err)
+	}
+
+	return u.update(ctx, winner.CustomerID, request)
+}
+
+// update applies an upsert to an existing customer
+func (u *Upserter) update(ctx context.Context, customerID string, request *stripe.CustomerRequest) (*stripe.Customer, bool, error) {
+	request.IdempotencyKey = ""
+	customer, err := u.provider.UpdateCustomer(ctx, customerID, request)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Customers created before identities were recorded have none to update
+	_, err = u.identities.Update(ctx, customerID, customer.Email, customer.Name)
+	if err != nil && !errors.Is(err, ErrIdentityNotFound) {
+		log.Printf("Failed to update identity for customer %s: %v", customerID, err)
+	}
+
+	return customer, false, nil
+}
+
+// upsertIdempotencyKey derives the provider idempotency key shared by every
+// creation of a tenant's customer with an external reference
+func upsertIdempotencyKey(tenantID, externalReference string) string {
+	sum := sha256.Sum256([]byte(tenantID + "\x00" + externalReference))
+	return "customer-upsert-" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,77 @@
+package customerstats
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"time"
+)
+
+// MaxWindowDays is the longest sliding window stats can be computed over
+const MaxWindowDays = 3650
+
+// ErrInvalidWindow is returned for windows outside 0 (lifetime) to MaxWindowDays days
+var ErrInvalidWindow = errors.New("days must be between 0 and 3650")
+
+// CurrencyStats aggregates a customer's charges in one currency. Amounts are
+// in the currency's minor units.
+type CurrencyStats struct {
+	Currency          string    `json:"currency"`
+	ChargeCount       int64     `json:"charge_count"`
+	SucceededCount    int64     `json:"succeeded_count"`
+	RefundedCount     int64     `json:"refunded_count"` // Succeeded charges with any amount refunded
+	DisputeCount      int64     `json:"dispute_count"`
+	GrossAmount       int64     `json:"gross_amount"` // Succeeded charges
+	RefundedAmount    int64     `json:"refunded_amount"`
+	LifetimeValue     int64     `json:"lifetime_value"` // Gross amount less refunds
+	AverageOrderValue int64     `json:"average_order_value"`
+	FirstChargeAt     time.Time `json:"first_charge_at"`
+	LastChargeAt      time.Time `json:"last_charge_at"`
+}
+
+// Stats summarizes a customer's charges over a window. Values are kept per
+// currency, since amounts in different currencies cannot be added up.
+type Stats struct {
+	CustomerID     string           `json:"customer_id"`
+	WindowDays     int              `json:"window_days,omitempty"` // Omitted for lifetime stats
+	ChargeCount    int64            `json:"charge_count"`
+	SucceededCount int64            `json:"succeeded_count"`
+	RefundRate     float64          `json:"refund_rate"` // Share of succeeded charges that were refunded
+	DisputeCount   int64            `json:"dispute_count"`
+	FirstChargeAt  *time.Time       `json:"first_charge_at,omitempty"`
+	LastChargeAt   *time.Time       `json:"last_charge_at,omitempty"`
+	Currencies     []*CurrencyStats `json:"currencies"`
+	ComputedAt     time.Time        `json:"computed_at"`
+}
+
+// Store aggregates the local copy of provider charges. Aggregates carry
+// counts, gross and refunded amounts and charge dates; derived values are
+// computed by the service.
+type Store interface {
+	GetCustomerChargeStats(ctx context.Context, customerID string, from time.Time) ([]*CurrencyStats, error)
+	GetChargeCustomer(ctx context.Context, chargeID string) (string, error)
+}
+
+// Config configures the stats cache
+type Config struct {
+	CacheTTL  time.Duration // Upper bound on staleness if an event is missed
+	CacheSize int           // Cached customer windows
+}
+
+// LoadConfig loads the stats configuration from environment variables
+func LoadConfig() *Config {
+	config := &Config{
+		CacheTTL:  5 * time.Minute,
+		CacheSize: 10000,
+	}
+
+	if seconds, err := strconv.Atoi(os.Getenv("CUSTOMER_STATS_CACHE_TTL_SECONDS")); err == nil && seconds >= 0 {
+		config.CacheTTL = time.Duration(seconds) * time.Second
+	}
+	if size, err := strconv.Atoi(os.Getenv("CUSTOMER_STATS_CACHE_SIZE")); err == nil && size >= 0 {
+		config.CacheSize = size
+	}
+
+	return config
+}
@@ -0,0 +1,154 @@
+package customerstats
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Service computes customer statistics from local charge data. Results are
+// cached per customer and window until a provider event changes one of the
+// customer's charges, or the cache TTL passes.
+type Service struct {
+	store  Store
+	config *Config
+	tracer trace.Tracer
+
+	mu           sync.Mutex
+	cache        map[string]map[int]*Stats // Customer ID to window days (0 for lifetime)
+	size         int
+	invalidation uint64 // Counts invalidations, so stats computed across one are not cached
+}
+
+// NewService creates a new customer stats service
+func NewService(store Store, config *Config) *Service {
+	return &Service{
+		store:  store,
+		config: config,
+		tracer: otel.Tracer("payments.customerstats"),
+		cache:  make(map[string]map[int]*Stats),
+	}
+}
+
+// Get returns the customer's stats over the last days days, or over their
+// lifetime when days is 0
+func (s *Service) Get(ctx context.Context, customerID string, days int) (*Stats, error) {
+	ctx, span := s.tracer.Start(ctx, "Get")
+	defer span.End()
+
+	if days < 0 || days > MaxWindowDays {
+		return nil, ErrInvalidWindow
+	}
+
+	stats, invalidation := s.cached(customerID, days)
+	if stats != nil {
+		return stats, nil
+	}
+
+	now := time.Now().UTC()
+	var from time.Time
+	if days > 0 {
+		from = now.AddDate(0, 0, -days)
+	}
+
+	currencies, err := s.store.GetCustomerChargeStats(ctx, customerID, from)
+	if err != nil {
+		return nil, err
+	}
+
+	stats = Summarize(customerID, currencies)
+	stats.WindowDays = days
+	stats.ComputedAt = now
+	s.remember(customerID, days, stats, invalidation)
+
+	return stats, nil
+}
+
+// Invalidate drops the customer's cached stats
+func (s *Service) Invalidate(customerID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.size -= len(s.cache[customerID])
+	delete(s.cache, customerID)
+	s.invalidation++
+}
+
+// Summarize derives a customer's stats from their per-currency aggregates
+func Summarize(customerID string, currencies []*CurrencyStats) *Stats {
+	stats := &Stats{
+		CustomerID: customerID,
+		Currencies: currencies,
+	}
+
+	var refunded int64
+	for _, currency := range currencies {
+		currency.LifetimeValue = currency.GrossAmount - currency.RefundedAmount
+		if currency.SucceededCount > 0 {
+			currency.AverageOrderValue = currency.GrossAmount / currency.SucceededCount
+		}
+
+		stats.ChargeCount += currency.ChargeCount
+		stats.SucceededCount += currency.SucceededCount
+		stats.DisputeCount += currency.DisputeCount
+		refunded += currency.RefundedCount
+
+		if first := currency.FirstChargeAt; stats.FirstChargeAt == nil || first.Before(*stats.FirstChargeAt) {
+			stats.FirstChargeAt = &first
+		}
+		if last := currency.LastChargeAt; stats.LastChargeAt == nil || last.After(*stats.LastChargeAt) {
+			stats.LastChargeAt = &last
+		}
+	}
+
+	if stats.SucceededCount > 0 {
+		stats.RefundRate = float64(refunded) / float64(stats.SucceededCount)
+	}
+
+	return stats
+}
+
+// cached returns the customer's unexpired cached stats for a window, along
+// with the invalidation count to pass to remember on a miss
+func (s *Service) cached(customerID string, days int) (*Stats, uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats, ok := s.cache[customerID][days]
+	if !ok || time.Since(stats.ComputedAt) >= s.config.CacheTTL {
+		return nil, s.invalidation
+	}
+	return stats, s.invalidation
+}
+
+// remember caches stats unless an invalidation happened while they were
+// computed, evicting another customer's when the cache is full
+func (s *Service) remember(customerID string, days int, stats *Stats, invalidation uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.config.CacheSize == 0 || s.config.CacheTTL == 0 || s.invalidation != invalidation {
+		return
+	}
+
+	windows, ok := s.cache[customerID]
+	if !ok {
+		for s.size >= s.config.CacheSize && len(s.cache) > 0 {
+			for evicted, evictedWindows := range s.cache {
+				s.size -= len(evictedWindows)
+				delete(s.cache, evicted)
+				break
+			}
+		}
+		windows = make(map[int]*Stats)
+		s.cache[customerID] = windows
+	}
+
+	if _, exists := windows[days]; !exists {
+		s.size++
+	}
+	windows[days] = stats
+}
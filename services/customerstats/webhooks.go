@@ -0,0 +1,79 @@
+package customerstats
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"apis/payments/services/stripe"
+
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// Events that change the charges stats are computed from
+var (
+	chargeEvents = []stripego.EventType{
+		stripego.EventTypeChargePending,
+		stripego.EventTypeChargeSucceeded,
+		stripego.EventTypeChargeFailed,
+		stripego.EventTypeChargeCaptured,
+		stripego.EventTypeChargeExpired,
+		stripego.EventTypeChargeUpdated,
+		stripego.EventTypeChargeRefunded,
+	}
+	disputeEvents = []stripego.EventType{
+		stripego.EventTypeChargeDisputeCreated,
+		stripego.EventTypeChargeDisputeClosed,
+	}
+)
+
+// RegisterWebhookHandlers drops cached stats when a customer's charges
+// change. Register it after the mirror, so stats recomputed afterwards see
+// the change.
+func (s *Service) RegisterWebhookHandlers(webhooks *stripe.WebhookService) {
+	for _, eventType := range chargeEvents {
+		webhooks.On(eventType, func(ctx context.Context, event stripego.Event) error {
+			var charge stripego.Charge
+			if err := json.Unmarshal(event.Data.Raw, &charge); err != nil {
+				return fmt.Errorf("failed to parse charge: %w", err)
+			}
+
+			if charge.Customer != nil {
+				s.Invalidate(charge.Customer.ID)
+			}
+			return nil
+		})
+	}
+
+	// Disputes carry only the charge, whose customer is looked up locally
+	for _, eventType := range disputeEvents {
+		webhooks.On(eventType, func(ctx context.Context, event stripego.Event) error {
+			var dispute stripego.Dispute
+			if err := json.Unmarshal(event.Data.Raw, &dispute); err != nil {
+				return fmt.Errorf("failed to parse dispute: %w", err)
+			}
+			if dispute.Charge == nil {
+				return nil
+			}
+
+			return s.invalidateCharge(ctx, dispute.Charge.ID)
+		})
+	}
+}
+
+// invalidateCharge drops the cached stats of a charge's customer. Charges
+// not stored locally have no stats to drop.
+func (s *Service) invalidateCharge(ctx context.Context, chargeID string) error {
+	customerID, err := s.store.GetChargeCustomer(ctx, chargeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up customer of charge %s: %w", chargeID, err)
+	}
+
+	s.Invalidate(customerID)
+	return nil
+}
@@ -0,0 +1,246 @@
+package customfields
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Limits on custom fields, matching Stripe's invoice custom fields so every
+// defined field can be printed on invoices
+const (
+	MaxFields      = 4
+	MaxLabelLength = 40
+	MaxValueLength = 140
+)
+
+// MetadataPrefix namespaces custom field values in provider metadata, e.g.
+// po_number is stored as cf_po_number
+const MetadataPrefix = "cf_"
+
+// TenantMetadataKey names the tenant whose fields a subscription's values
+// belong to, so its invoices can be labelled from webhooks
+const TenantMetadataKey = "tenant_id"
+
+// DefaultTenantID is the tenant of resources created without X-Tenant-ID
+const DefaultTenantID = "default"
+
+// ErrInvalidCustomFields is returned when values do not match the tenant's fields
+var ErrInvalidCustomFields = errors.New("custom fields do not match definition")
+
+// ErrInvalidDefinition is returned when custom fields cannot be defined
+var ErrInvalidDefinition = errors.New("invalid custom field definition")
+
+// keyPattern limits keys so that prefixed keys fit Stripe's 40 character metadata keys
+var keyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,36}$`)
+
+// Field is a custom field a tenant collects, e.g. a PO number
+type Field struct {
+	Key       string   `json:"key"`
+	Label     string   `json:"label"` // Printed on receipts and invoices
+	Required  bool     `json:"required,omitempty"`
+	Enum      []string `json:"enum,omitempty"`
+	Pattern   string   `json:"pattern,omitempty"`
+	MaxLength int      `json:"max_length,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// Definition is the ordered set of custom fields a tenant collects
+type Definition struct {
+	TenantID  string    `json:"tenant_id"`
+	Fields    []*Field  `json:"fields"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Value is a custom field value as rendered on receipts and invoices
+type Value struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+
+// Compile checks the definition and prepares it for validation
+func (d *Definition) Compile() error {
+	if len(d.Fields) > MaxFields {
+		return fmt.Errorf("%w: at most %d fields can be defined", ErrInvalidDefinition, MaxFields)
+	}
+
+	seen := make(map[string]bool, len(d.Fields))
+	for _, field := range d.Fields {
+		if field == nil {
+			return fmt.Errorf("%w: field has no definition", ErrInvalidDefinition)
+		}
+		if !keyPattern.MatchString(field.Key) {
+			return fmt.Errorf("%w: key %q must be lowercase letters, digits and underscores, at most 37 characters", ErrInvalidDefinition, field.Key)
+		}
+		if seen[field.Key] {
+			return fmt.Errorf("%w: key %q is defined twice", ErrInvalidDefinition, field.Key)
+		}
+		seen[field.Key] = true
+
+		if strings.TrimSpace(field.Label) == "" {
+			return fmt.Errorf("%w: field %q has no label", ErrInvalidDefinition, field.Key)
+		}
+		if utf8.RuneCountInString(field.Label) > MaxLabelLength {
+			return fmt.Errorf("%w: field %q label must be at most %d characters", ErrInvalidDefinition, field.Key, MaxLabelLength)
+		}
+		if field.MaxLength < 0 || field.MaxLength > MaxValueLength {
+			return fmt.Errorf("%w: field %q max_length must be between 0 and %d", ErrInvalidDefinition, field.Key, MaxValueLength)
+		}
+		for _, allowed := range field.Enum {
+			if allowed == "" || utf8.RuneCountInString(allowed) > MaxValueLength {
+				return fmt.Errorf("%w: field %q enum values must be 1 to %d characters", ErrInvalidDefinition, field.Key, MaxValueLength)
+			}
+		}
+
+		if field.Pattern != "" {
+			pattern, err := regexp.Compile(field.Pattern)
+			if err != nil {
+				return fmt.Errorf("%w: field %q has invalid pattern: %v", ErrInvalidDefinition, field.Key, err)
+			}
+			field.pattern = pattern
+		}
+	}
+
+	return nil
+}
+
+// Validate checks values against a compiled definition and returns every problem found
+func (d *Definition) Validate(values map[string]string) error {
+	var problems []string
+
+	for _, field := range d.Fields {
+		if _, ok := values[field.Key]; !ok && field.Required {
+			problems = append(problems, fmt.Sprintf("%s is required", field.Key))
+		}
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		field := d.field(key)
+		if field == nil {
+			problems = append(problems, fmt.Sprintf("%s is not a defined field", key))
+			continue
+		}
+
+		if problem := field.check(values[key]); problem != "" {
+			problems = append(problems, fmt.Sprintf("%s %s", key, problem))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidCustomFields, strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// Render labels values in the order the fields are defined. Values of fields
+// that are no longer defined are left out.
+func (d *Definition) Render(values map[string]string) []Value {
+	var rendered []Value
+	for _, field := range d.Fields {
+		if value, ok := values[field.Key]; ok && value != "" {
+			rendered = append(rendered, Value{Key: field.Key, Label: field.Label, Value: value})
+		}
+	}
+	return rendered
+}
+
+// field returns the field with a key, or nil if none is defined
+func (d *Definition) field(key string) *Field {
+	for _, field := range d.Fields {
+		if field.Key == key {
+			return field
+		}
+	}
+	return nil
+}
+
+// check returns a description of why the value is invalid, or "" if it is valid
+func (f *Field) check(value string) string {
+	if strings.TrimSpace(value) == "" {
+		return "cannot be empty"
+	}
+
+	maxLength := MaxValueLength
+	if f.MaxLength > 0 {
+		maxLength = f.MaxLength
+	}
+	if utf8.RuneCountInString(value) > maxLength {
+		return fmt.Sprintf("must be at most %d characters", maxLength)
+	}
+
+	if f.pattern != nil && !f.pattern.MatchString(value) {
+		return fmt.Sprintf("must match %s", f.Pattern)
+	}
+
+	if len(f.Enum) > 0 {
+		for _, allowed := range f.Enum {
+			if value == allowed {
+				return ""
+			}
+		}
+		return fmt.Sprintf("must be one of %s", strings.Join(f.Enum, ", "))
+	}
+
+	return ""
+}
+
+// ToMetadata adds custom field values to provider metadata under their
+// prefixed keys, returning the merged metadata
+func ToMetadata(metadata, values map[string]string) map[string]string {
+	if len(values) == 0 {
+		return metadata
+	}
+
+	merged := make(map[string]string, len(metadata)+len(values))
+	for key, value := range metadata {
+		merged[key] = value
+	}
+	for key, value := range values {
+		merged[MetadataPrefix+key] = value
+	}
+	return merged
+}
+
+// FromMetadata extracts custom field values from provider metadata
+func FromMetadata(metadata map[string]string) map[string]string {
+	values := make(map[string]string)
+	for key, value := range metadata {
+		if field, ok := strings.CutPrefix(key, MetadataPrefix); ok && field != "" {
+			values[field] = value
+		}
+	}
+	return values
+}
+
+// ReceiptDescription appends rendered fields to a charge description, which
+// providers print on receipts, e.g. "Order 42 (PO number: 1234; Cost center: R&D)"
+func ReceiptDescription(description string, rendered []Value) string {
+	if len(rendered) == 0 {
+		return description
+	}
+
+	parts := make([]string, len(rendered))
+	for i, value := range rendered {
+		parts[i] = value.Label + ": " + value.Value
+	}
+	fields := strings.Join(parts, "; ")
+
+	if description == "" {
+		return fields
+	}
+	return description + " (" + fields + ")"
+}
@@ -0,0 +1,141 @@
+package customfields
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"apis/payments/services/stripe"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Store persists tenant custom field definitions
+type Store interface {
+	GetCustomFieldDefinition(ctx context.Context, tenantID string) (*Definition, error)
+	UpsertCustomFieldDefinition(ctx context.Context, definition *Definition) (*Definition, error)
+	DeleteCustomFieldDefinition(ctx context.Context, tenantID string) (bool, error)
+}
+
+// InvoiceProvider prints custom fields on provider invoices
+type InvoiceProvider interface {
+	SetInvoiceCustomFields(ctx context.Context, invoiceID string, fields []stripe.InvoiceCustomField) (*stripe.Invoice, error)
+}
+
+// Service defines tenant custom fields, validates the values collected on
+// charges and invoiced subscriptions, and renders them for receipts and invoices
+type Service struct {
+	store    Store
+	invoices InvoiceProvider
+	tracer   trace.Tracer
+}
+
+// NewService creates a new custom field service
+func NewService(store Store, invoices InvoiceProvider) *Service {
+	return &Service{
+		store:    store,
+		invoices: invoices,
+		tracer:   otel.Tracer("payments.customfields"),
+	}
+}
+
+// GetDefinition returns the custom fields a tenant has defined
+func (s *Service) GetDefinition(ctx context.Context, tenantID string) (*Definition, error) {
+	ctx, span := s.tracer.Start(ctx, "GetDefinition")
+	defer span.End()
+
+	definition, err := s.store.GetCustomFieldDefinition(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get custom fields: %w", err)
+	}
+
+	return definition, nil
+}
+
+// Define stores a tenant's custom fields, replacing any existing ones
+func (s *Service) Define(ctx context.Context, tenantID string, fields []*Field) (*Definition, error) {
+	ctx, span := s.tracer.Start(ctx, "Define")
+	defer span.End()
+
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("%w: at least one field is required", ErrInvalidDefinition)
+	}
+
+	definition := &Definition{TenantID: tenantID, Fields: fields}
+	if err := definition.Compile(); err != nil {
+		return nil, err
+	}
+
+	return s.store.UpsertCustomFieldDefinition(ctx, definition)
+}
+
+// DeleteDefinition removes a tenant's custom fields, reporting whether any were defined
+func (s *Service) DeleteDefinition(ctx context.Context, tenantID string) (bool, error) {
+	ctx, span := s.tracer.Start(ctx, "DeleteDefinition")
+	defer span.End()
+
+	return s.store.DeleteCustomFieldDefinition(ctx, tenantID)
+}
+
+// Collect validates the custom field values sent with a charge or invoiced
+// subscription and renders them with their labels. Required fields are
+// enforced even when no values are sent.
+func (s *Service) Collect(ctx context.Context, tenantID string, values map[string]string) ([]Value, error) {
+	ctx, span := s.tracer.Start(ctx, "Collect")
+	defer span.End()
+
+	definition, err := s.definition(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if definition == nil {
+		if len(values) > 0 {
+			return nil, fmt.Errorf("%w: no custom fields are defined", ErrInvalidCustomFields)
+		}
+		return nil, nil
+	}
+
+	if err := definition.Validate(values); err != nil {
+		return nil, err
+	}
+
+	return definition.Render(values), nil
+}
+
+// Render labels values stored in a resource's metadata with the tenant's
+// current fields
+func (s *Service) Render(ctx context.Context, tenantID string, metadata map[string]string) ([]Value, error) {
+	ctx, span := s.tracer.Start(ctx, "Render")
+	defer span.End()
+
+	values := FromMetadata(metadata)
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	definition, err := s.definition(ctx, tenantID)
+	if err != nil || definition == nil {
+		return nil, err
+	}
+
+	return definition.Render(values), nil
+}
+
+// definition returns the tenant's compiled definition, or nil if none is defined
+func (s *Service) definition(ctx context.Context, tenantID string) (*Definition, error) {
+	definition, err := s.store.GetCustomFieldDefinition(ctx, tenantID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get custom fields: %w", err)
+	}
+
+	if err := definition.Compile(); err != nil {
+		return nil, err
+	}
+
+	return definition, nil
+}
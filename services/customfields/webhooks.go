@@ -0,0 +1,58 @@
+package customfields
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"apis/payments/services/stripe"
+
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// RegisterWebhookHandlers prints a subscription's custom fields on each
+// invoice it creates, while the invoice is still a draft
+func (s *Service) RegisterWebhookHandlers(webhooks *stripe.WebhookService) {
+	webhooks.On(stripego.EventTypeInvoiceCreated, func(ctx context.Context, event stripego.Event) error {
+		var stripeInvoice stripego.Invoice
+		if err := json.Unmarshal(event.Data.Raw, &stripeInvoice); err != nil {
+			return fmt.Errorf("failed to parse invoice: %w", err)
+		}
+
+		return s.LabelInvoice(ctx, &stripeInvoice)
+	})
+}
+
+// LabelInvoice copies the custom field values stored on an invoice's
+// subscription onto the invoice. Finalized invoices cannot be changed and
+// are left alone.
+func (s *Service) LabelInvoice(ctx context.Context, stripeInvoice *stripego.Invoice) error {
+	ctx, span := s.tracer.Start(ctx, "LabelInvoice")
+	defer span.End()
+
+	if stripeInvoice.Status != stripego.InvoiceStatusDraft || stripeInvoice.SubscriptionDetails == nil {
+		return nil
+	}
+
+	metadata := stripeInvoice.SubscriptionDetails.Metadata
+	tenantID := metadata[TenantMetadataKey]
+	if tenantID == "" {
+		tenantID = DefaultTenantID
+	}
+
+	rendered, err := s.Render(ctx, tenantID, metadata)
+	if err != nil || len(rendered) == 0 {
+		return err
+	}
+
+	fields := make([]stripe.InvoiceCustomField, len(rendered))
+	for i, value := range rendered {
+		fields[i] = stripe.InvoiceCustomField{Name: value.Label, Value: value.Value}
+	}
+
+	if _, err := s.invoices.SetInvoiceCustomFields(ctx, stripeInvoice.ID, fields); err != nil {
+		return fmt.Errorf("failed to label invoice %s: %w", stripeInvoice.ID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package deadletter
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Dead-letter entry statuses
+const (
+	StatusPending   = "pending"   // Waiting for its next automatic retry
+	StatusResolved  = "resolved"  // Replayed successfully
+	StatusExhausted = "exhausted" // Out of automatic retries; retry manually or purge
+)
+
+// Sources of dead-lettered events
+const (
+	SourceStripeWebhook = "stripe_webhook"
+	SourceKafkaCommand  = "kafka_command"
+)
+
+// ErrNoReplayer is returned when retrying an entry whose source has no replayer
+var ErrNoReplayer = errors.New("no replayer registered for dead-letter source")
+
+// ErrAlreadyResolved is returned when retrying an entry that already succeeded
+var ErrAlreadyResolved = errors.New("dead-letter entry is already resolved")
+
+// ErrInvalidPurge is returned for purges that would remove pending entries
+var ErrInvalidPurge = errors.New("invalid dead-letter purge")
+
+// Entry is an event that failed processing, kept until it is replayed
+// successfully or purged
+type Entry struct {
+	ID            string            `json:"id"`
+	Source        string            `json:"source"`
+	Key           string            `json:"key"` // Identifies the event within its source, e.g. the webhook event ID
+	Payload       []byte            `json:"payload"`
+	Headers       map[string]string `json:"headers,omitempty"`
+	Status        string            `json:"status"`
+	Attempts      int               `json:"attempts"` // Replays made from the dead-letter queue
+	LastError     string            `json:"last_error"`
+	NextAttemptAt *time.Time        `json:"next_attempt_at,omitempty"`
+	ResolvedAt    *time.Time        `json:"resolved_at,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
+
+// Filter narrows dead-letter listings
+type Filter struct {
+	Status string
+	Source string
+	Limit  int
+}
+
+// RetryResult summarizes a retry run
+type RetryResult struct {
+	Attempted int `json:"attempted"`
+	Resolved  int `json:"resolved"`
+	Failed    int `json:"failed"`
+	Exhausted int `json:"exhausted"`
+}
+
+// Replayer processes a dead-lettered event again
+type Replayer interface {
+	Replay(ctx context.Context, entry *Entry) error
+}
+
+// ReplayerFunc adapts a function to the Replayer interface
+type ReplayerFunc func(ctx context.Context, entry *Entry) error
+
+// Replay calls f(ctx, entry)
+func (f ReplayerFunc) Replay(ctx context.Context, entry *Entry) error {
+	return f(ctx, entry)
+}
+
+// Config controls automatic retries
+type Config struct {
+	RetryEnabled bool
+	Interval     time.Duration // How often due entries are retried
+	MaxAttempts  int           // Automatic retries before an entry is exhausted
+	Backoff      time.Duration // Delay before the first retry, doubled after each failure
+	MaxBackoff   time.Duration
+	BatchSize    int // Entries retried per run
+}
+
+// LoadConfig loads the dead-letter retry configuration from environment variables
+func LoadConfig() *Config {
+	config := &Config{
+		RetryEnabled: true,
+		Interval:     30 * time.Second,
+		MaxAttempts:  8,
+		Backoff:      time.Minute,
+		MaxBackoff:   6 * time.Hour,
+		BatchSize:    50,
+	}
+
+	if enabled, err := strconv.ParseBool(os.Getenv("DLQ_RETRY_ENABLED")); err == nil {
+		config.RetryEnabled = enabled
+	}
+	if seconds, err := strconv.Atoi(os.Getenv("DLQ_RETRY_INTERVAL_SECONDS")); err == nil && seconds > 0 {
+		config.Interval = time.Duration(seconds) * time.Second
+	}
+	if attempts, err := strconv.Atoi(os.Getenv("DLQ_MAX_ATTEMPTS")); err == nil && attempts > 0 {
+		config.MaxAttempts = attempts
+	}
+	if seconds, err := strconv.Atoi(os.Getenv("DLQ_RETRY_BACKOFF_SECONDS")); err == nil && seconds > 0 {
+		config.Backoff = time.Duration(seconds) * time.Second
+	}
+	if seconds, err := strconv.Atoi(os.Getenv("DLQ_RETRY_MAX_BACKOFF_SECONDS")); err == nil && seconds > 0 {
+		config.MaxBackoff = time.Duration(seconds) * time.Second
+	}
+	if config.MaxBackoff < config.Backoff {
+		config.MaxBackoff = config.Backoff
+	}
+
+	return config
+}
+
+// backoff returns the delay before the retry following a number of failed attempts
+func (c *Config) backoff(failures int) time.Duration {
+	delay := c.Backoff
+	for i := 1; i < failures && delay < c.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > c.MaxBackoff {
+		delay = c.MaxBackoff
+	}
+	return delay
+}
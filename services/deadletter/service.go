@@ -0,0 +1,237 @@
+package deadletter
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Store persists dead-letter entries
+type Store interface {
+	CreateDeadLetter(ctx context.Context, entry *Entry) (*Entry, error)
+	GetDeadLetter(ctx context.Context, id string) (*Entry, error)
+	ListDeadLetters(ctx context.Context, filter Filter) ([]*Entry, error)
+	ClaimDueDeadLetters(ctx context.Context, leaseUntil time.Time, limit int) ([]*Entry, error)
+	UpdateDeadLetter(ctx context.Context, entry *Entry) (*Entry, error)
+	DeleteDeadLetter(ctx context.Context, id string) (bool, error)
+	PurgeDeadLetters(ctx context.Context, status string, before time.Time) (int64, error)
+}
+
+// leaseDuration is how long a claimed entry is hidden from other workers
+// while it is retried
+const leaseDuration = 5 * time.Minute
+
+// Service keeps events that failed processing in durable storage and
+// replays them with exponential backoff
+type Service struct {
+	store     Store
+	config    *Config
+	replayers map[string]Replayer
+	tracer    trace.Tracer
+}
+
+// NewService creates a new dead-letter service
+func NewService(store Store, config *Config) *Service {
+	return &Service{
+		store:     store,
+		config:    config,
+		replayers: make(map[string]Replayer),
+		tracer:    otel.Tracer("payments.deadletter"),
+	}
+}
+
+// Register sets the replayer for a source's entries
+func (s *Service) Register(source string, replayer Replayer) {
+	s.replayers[source] = replayer
+}
+
+// Record stores a failed event for retry. Recording the same source and key
+// again updates the stored error rather than adding an entry.
+func (s *Service) Record(ctx context.Context, source, key string, payload []byte, headers map[string]string, cause error) (*Entry, error) {
+	ctx, span := s.tracer.Start(ctx, "Record")
+	defer span.End()
+
+	next := time.Now().Add(s.config.backoff(1))
+	entry, err := s.store.CreateDeadLetter(ctx, &Entry{
+		ID:            "dlq_" + uuid.New().String(),
+		Source:        source,
+		Key:           key,
+		Payload:       payload,
+		Headers:       headers,
+		Status:        StatusPending,
+		LastError:     cause.Error(),
+		NextAttemptAt: &next,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record dead letter: %w", err)
+	}
+
+	return entry, nil
+}
+
+// Get returns a dead-letter entry
+func (s *Service) Get(ctx context.Context, id string) (*Entry, error) {
+	ctx, span := s.tracer.Start(ctx, "Get")
+	defer span.End()
+
+	return s.store.GetDeadLetter(ctx, id)
+}
+
+// List returns dead-letter entries, newest first
+func (s *Service) List(ctx context.Context, filter Filter) ([]*Entry, error) {
+	ctx, span := s.tracer.Start(ctx, "List")
+	defer span.End()
+
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 100
+	}
+	return s.store.ListDeadLetters(ctx, filter)
+}
+
+// Retry replays an entry now, whether it is pending or exhausted
+func (s *Service) Retry(ctx context.Context, id string) (*Entry, error) {
+	ctx, span := s.tracer.Start(ctx, "Retry")
+	defer span.End()
+
+	entry, err := s.store.GetDeadLetter(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Status == StatusResolved {
+		return nil, ErrAlreadyResolved
+	}
+
+	return s.attempt(ctx, entry)
+}
+
+// RetryDue replays the pending entries whose backoff has elapsed
+func (s *Service) RetryDue(ctx context.Context) (RetryResult, error) {
+	ctx, span := s.tracer.Start(ctx, "RetryDue")
+	defer span.End()
+
+	var result RetryResult
+
+	// Claimed entries are leased so concurrent workers skip them
+	entries, err := s.store.ClaimDueDeadLetters(ctx, time.Now().Add(leaseDuration), s.config.BatchSize)
+	if err != nil {
+		return result, fmt.Errorf("failed to claim dead letters: %w", err)
+	}
+
+	for _, entry := range entries {
+		result.Attempted++
+		updated, err := s.attempt(ctx, entry)
+		switch {
+		case updated == nil:
+			log.Printf("Failed to retry dead letter %s: %v", entry.ID, err)
+			result.Failed++
+		case updated.Status == StatusResolved:
+			result.Resolved++
+		case updated.Status == StatusExhausted:
+			result.Exhausted++
+		default:
+			result.Failed++
+		}
+	}
+
+	return result, nil
+}
+
+// Delete removes an entry, reporting whether it existed
+func (s *Service) Delete(ctx context.Context, id string) (bool, error) {
+	ctx, span := s.tracer.Start(ctx, "Delete")
+	defer span.End()
+
+	return s.store.DeleteDeadLetter(ctx, id)
+}
+
+// Purge removes resolved or exhausted entries created before a time. Pending
+// entries are still being retried and cannot be purged.
+func (s *Service) Purge(ctx context.Context, status string, before time.Time) (int64, error) {
+	ctx, span := s.tracer.Start(ctx, "Purge")
+	defer span.End()
+
+	if status != StatusResolved && status != StatusExhausted {
+		return 0, fmt.Errorf("%w: status must be %s or %s", ErrInvalidPurge, StatusResolved, StatusExhausted)
+	}
+	if before.IsZero() {
+		before = time.Now()
+	}
+
+	return s.store.PurgeDeadLetters(ctx, status, before)
+}
+
+// Start retries due entries periodically until stop is called
+func (s *Service) Start() (stop func()) {
+	if !s.config.RetryEnabled {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(s.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				result, err := s.RetryDue(context.Background())
+				if err != nil {
+					log.Printf("Dead-letter retry failed: %v", err)
+				}
+				if result.Attempted > 0 {
+					log.Printf("Dead-letter retry resolved %d, failed %d, exhausted %d of %d entries", result.Resolved, result.Failed, result.Exhausted, result.Attempted)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// attempt replays an entry and records the outcome. Failures schedule the
+// next retry with exponential backoff until attempts run out.
+func (s *Service) attempt(ctx context.Context, entry *Entry) (*Entry, error) {
+	replayer, ok := s.replayers[entry.Source]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoReplayer, entry.Source)
+	}
+
+	replayErr := replayer.Replay(ctx, entry)
+	entry.Attempts++
+
+	now := time.Now()
+	if replayErr == nil {
+		entry.Status = StatusResolved
+		entry.ResolvedAt = &now
+		entry.NextAttemptAt = nil
+	} else {
+		entry.LastError = replayErr.Error()
+		if entry.Status == StatusExhausted || entry.Attempts >= s.config.MaxAttempts {
+			entry.Status = StatusExhausted
+			entry.NextAttemptAt = nil
+		} else {
+			next := now.Add(s.config.backoff(entry.Attempts + 1))
+			entry.NextAttemptAt = &next
+		}
+	}
+
+	updated, err := s.store.UpdateDeadLetter(ctx, entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update dead letter: %w", err)
+	}
+
+	return updated, nil
+}
@@ -0,0 +1,56 @@
+package deprecation
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Notice marks a route or field as deprecated
+type Notice struct {
+	ID         string    `json:"id"`
+	Surface    string    `json:"surface"` // e.g. "POST /api/v1/charges" or "ChargeRequest.source"
+	Message    string    `json:"message"`
+	Deprecated time.Time `json:"deprecated"`
+	Sunset     time.Time `json:"sunset,omitempty"`
+	Link       string    `json:"link,omitempty"` // Migration guide
+}
+
+// Headers returns the Deprecation (RFC 9745), Sunset (RFC 8594) and Link
+// headers announcing the notice to callers
+func (n Notice) Headers() map[string]string {
+	headers := map[string]string{
+		"Deprecation": fmt.Sprintf("@%d", n.Deprecated.Unix()),
+	}
+	if !n.Sunset.IsZero() {
+		headers["Sunset"] = n.Sunset.UTC().Format(http.TimeFormat)
+	}
+	if n.Link != "" {
+		headers["Link"] = fmt.Sprintf(`<%s>; rel="deprecation"; type="text/html"`, n.Link)
+	}
+	return headers
+}
+
+// Usage counts requests to a deprecated surface made with one API key
+type Usage struct {
+	NoticeID  string    `json:"notice_id"`
+	APIKeyID  string    `json:"api_key_id"`
+	Count     int64     `json:"count"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// ReportEntry summarizes recorded usage of one notice
+type ReportEntry struct {
+	Notice     Notice   `json:"notice"`
+	TotalCount int64    `json:"total_count"`
+	Callers    []*Usage `json:"callers"`
+}
+
+// Store persists deprecated usage counts. RecordDeprecatedUsage adds the
+// count to any existing total for the notice and API key.
+type Store interface {
+	RecordDeprecatedUsage(ctx context.Context, usage *Usage) error
+	ListDeprecatedUsage(ctx context.Context) ([]*Usage, error)
+}
@@ -0,0 +1,186 @@
+package deprecation
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// usageKey identifies pending usage of a notice by an API key
+type usageKey struct {
+	noticeID string
+	apiKeyID string
+}
+
+// Service holds the registered deprecation notices and counts their usage.
+// Usage is aggregated in memory and flushed to the store periodically so
+// deprecated requests don't pay for a database write.
+type Service struct {
+	store   Store
+	notices map[string]Notice
+	pending map[usageKey]*Usage
+	mu      sync.Mutex
+	tracer  trace.Tracer
+}
+
+// NewService creates a new deprecation service
+func NewService(store Store) *Service {
+	return &Service{
+		store:   store,
+		notices: make(map[string]Notice),
+		pending: make(map[usageKey]*Usage),
+		tracer:  otel.Tracer("payments.deprecation"),
+	}
+}
+
+// Register adds a deprecation notice
+func (s *Service) Register(notice Notice) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.notices[notice.ID] = notice
+}
+
+// Notice returns a registered notice
+func (s *Service) Notice(id string) (Notice, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	notice, ok := s.notices[id]
+	return notice, ok
+}
+
+// Track counts one use of a deprecated surface by an API key
+func (s *Service) Track(noticeID, apiKeyID string) {
+	now := time.Now().UTC()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := usageKey{noticeID: noticeID, apiKeyID: apiKeyID}
+	usage, ok := s.pending[key]
+	if !ok {
+		usage = &Usage{NoticeID: noticeID, APIKeyID: apiKeyID, FirstSeen: now}
+		s.pending[key] = usage
+	}
+	usage.Count++
+	usage.LastSeen = now
+}
+
+// Flush writes pending usage to the store. Usage that fails to write is
+// kept and retried on the next flush.
+func (s *Service) Flush(ctx context.Context) error {
+	ctx, span := s.tracer.Start(ctx, "Flush")
+	defer span.End()
+
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = make(map[usageKey]*Usage)
+	s.mu.Unlock()
+
+	var firstErr error
+	for key, usage := range pending {
+		if err := s.store.RecordDeprecatedUsage(ctx, usage); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to flush deprecated usage: %w", err)
+			}
+			s.requeue(key, usage)
+		}
+	}
+
+	return firstErr
+}
+
+// requeue merges unflushed usage back into the pending counts
+func (s *Service) requeue(key usageKey, usage *Usage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.pending[key]
+	if !ok {
+		s.pending[key] = usage
+		return
+	}
+	existing.Count += usage.Count
+	existing.FirstSeen = usage.FirstSeen
+}
+
+// Start flushes usage every interval until the returned stop function is
+// called. Stop performs a final flush.
+func (s *Service) Start(interval time.Duration) (stop func(ctx context.Context)) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.Flush(context.Background()); err != nil {
+					log.Printf("Deprecation usage flush failed: %v", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func(ctx context.Context) {
+		close(done)
+		<-stopped
+		if err := s.Flush(ctx); err != nil {
+			log.Printf("Deprecation usage flush failed: %v", err)
+		}
+	}
+}
+
+// Report flushes pending usage and returns usage of every registered notice,
+// including notices nobody has used
+func (s *Service) Report(ctx context.Context) ([]*ReportEntry, error) {
+	ctx, span := s.tracer.Start(ctx, "Report")
+	defer span.End()
+
+	if err := s.Flush(ctx); err != nil {
+		return nil, err
+	}
+
+	usages, err := s.store.ListDeprecatedUsage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	entries := make(map[string]*ReportEntry, len(s.notices))
+	for id, notice := range s.notices {
+		entries[id] = &ReportEntry{Notice: notice, Callers: []*Usage{}}
+	}
+	s.mu.Unlock()
+
+	for _, usage := range usages {
+		entry, ok := entries[usage.NoticeID]
+		if !ok {
+			// Usage of a notice that has since been removed
+			entry = &ReportEntry{Notice: Notice{ID: usage.NoticeID}, Callers: []*Usage{}}
+			entries[usage.NoticeID] = entry
+		}
+		entry.TotalCount += usage.Count
+		entry.Callers = append(entry.Callers, usage)
+	}
+
+	report := make([]*ReportEntry, 0, len(entries))
+	for _, entry := range entries {
+		report = append(report, entry)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		return report[i].Notice.ID < report[j].Notice.ID
+	})
+
+	return report, nil
+}
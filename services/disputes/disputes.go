@@ -0,0 +1,195 @@
+package disputes
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"apis/payments/services/config"
+	"apis/payments/services/stripe"
+)
+
+// EventEvidenceDueSoon is emitted once per evidence deadline when a dispute
+// that still needs a response nears it
+const EventEvidenceDueSoon = "payments.dispute.evidence_due_soon"
+
+// Errors returned by the evidence service
+var (
+	ErrInvalidEvidence = errors.New("invalid dispute evidence")
+	ErrDisputeClosed   = errors.New("dispute no longer accepts evidence")
+	ErrEvidencePastDue = errors.New("dispute evidence deadline has passed")
+	ErrNoEvidence      = errors.New("no evidence staged for dispute")
+)
+
+// respondableStatuses are the dispute statuses evidence can be submitted in
+var respondableStatuses = map[string]bool{
+	"needs_response":         true,
+	"warning_needs_response": true,
+}
+
+// closedStatuses are the dispute statuses with a final outcome
+var closedStatuses = map[string]bool{
+	"won":             true,
+	"lost":            true,
+	"warning_closed":  true,
+	"charge_refunded": true,
+}
+
+// IsOpen reports whether a dispute in a status is still awaiting an outcome
+func IsOpen(status string) bool {
+	return !closedStatuses[status]
+}
+
+// textEvidenceFields are Stripe's free-text evidence fields
+var textEvidenceFields = map[string]bool{
+	"access_activity_log":            true,
+	"billing_address":                true,
+	"cancellation_policy_disclosure": true,
+	"cancellation_rebuttal":          true,
+	"customer_email_address":         true,
+	"customer_name":                  true,
+	"customer_purchase_ip":           true,
+	"duplicate_charge_explanation":   true,
+	"duplicate_charge_id":            true,
+	"product_description":            true,
+	"refund_policy_disclosure":       true,
+	"refund_refusal_explanation":     true,
+	"service_date":                   true,
+	"shipping_address":               true,
+	"shipping_carrier":               true,
+	"shipping_date":                  true,
+	"shipping_tracking_number":       true,
+	"uncategorized_text":             true,
+}
+
+// fileEvidenceFields are Stripe's evidence fields that take an uploaded file
+var fileEvidenceFields = map[string]bool{
+	"cancellation_policy":            true,
+	"customer_communication":         true,
+	"customer_signature":             true,
+	"duplicate_charge_documentation": true,
+	"receipt":                        true,
+	"refund_policy":                  true,
+	"service_documentation":          true,
+	"shipping_documentation":         true,
+	"uncategorized_file":             true,
+}
+
+// evidenceContentTypes are the file types Stripe accepts as evidence
+var evidenceContentTypes = map[string]bool{
+	"application/pdf": true,
+	"image/jpeg":      true,
+	"image/png":       true,
+}
+
+// maxEvidenceText is Stripe's limit on the combined length of text evidence
+const maxEvidenceText = 150000
+
+// Filter narrows a dispute listing
+type Filter struct {
+	ChargeID string
+	Status   string
+	Limit    int
+}
+
+// Config holds dispute evidence configuration
+type Config struct {
+	// ReminderLead is how long before an evidence deadline the
+	// evidence_due_soon reminder is sent
+	ReminderLead time.Duration
+	// ReminderInterval is how often deadlines are checked
+	ReminderInterval time.Duration
+	// MaxFileSize is the largest evidence file accepted, in bytes
+	MaxFileSize int64
+}
+
+// LoadConfig loads dispute evidence configuration from environment variables
+func LoadConfig() *Config {
+	return &Config{
+		ReminderLead:     time.Duration(config.Int("DISPUTE_EVIDENCE_REMINDER_HOURS", 72)) * time.Hour,
+		ReminderInterval: time.Duration(config.Int("DISPUTE_EVIDENCE_REMINDER_INTERVAL_MINUTES", 60)) * time.Minute,
+		MaxFileSize:      int64(config.Int("DISPUTE_EVIDENCE_MAX_FILE_MB", 5)) << 20,
+	}
+}
+
+// Evidence is the evidence staged for a dispute, keyed by Stripe's evidence
+// field names. File fields hold provider file IDs.
+type Evidence struct {
+	DisputeID   string            `json:"dispute_id"`
+	Status      string            `json:"status"`
+	Fields      map[string]string `json:"fields"`
+	Files       []*EvidenceFile   `json:"files"`
+	DueBy       *time.Time        `json:"due_by,omitempty"`
+	PastDue     bool              `json:"past_due"`
+	UpdatedBy   string            `json:"updated_by,omitempty"`
+	SubmittedBy string            `json:"submitted_by,omitempty"`
+	SubmittedAt *time.Time        `json:"submitted_at,omitempty"`
+	UpdatedAt   time.Time         `json:"updated_at,omitempty"`
+}
+
+// EvidenceFile is a file uploaded as evidence for a dispute
+type EvidenceFile struct {
+	ID             string    `json:"id"`
+	DisputeID      string    `json:"dispute_id"`
+	ProviderFileID string    `json:"provider_file_id"`
+	Field          string    `json:"field,omitempty"`
+	Filename       string    `json:"filename"`
+	ContentType    string    `json:"content_type"`
+	Size           int64     `json:"size"`
+	UploadedBy     string    `json:"uploaded_by,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Upload is an evidence file to store, optionally attached to a file field.
+// Its content type is detected from the content.
+type Upload struct {
+	Field      string
+	Filename   string
+	Content    io.Reader
+	UploadedBy string
+}
+
+// Reminder is the payload of an evidence_due_soon event
+type Reminder struct {
+	DisputeID      string    `json:"dispute_id"`
+	ChargeID       string    `json:"charge_id"`
+	Amount         int64     `json:"amount"`
+	Currency       string    `json:"currency"`
+	Reason         string    `json:"reason"`
+	Status         string    `json:"status"`
+	DueBy          time.Time `json:"due_by"`
+	StagedEvidence bool      `json:"staged_evidence"`
+}
+
+// Store persists disputes. Upserts older than the stored row are ignored so
+// out-of-order webhooks cannot roll a dispute back.
+type Store interface {
+	UpsertDispute(ctx context.Context, dispute *stripe.Dispute, syncedAt time.Time) error
+	GetDispute(ctx context.Context, disputeID string) (*stripe.Dispute, error)
+	ListDisputes(ctx context.Context, filter Filter) ([]*stripe.Dispute, error)
+}
+
+// EvidenceStore persists staged evidence, evidence files and reminders
+type EvidenceStore interface {
+	GetDisputeEvidence(ctx context.Context, disputeID string) (*Evidence, error)
+	SaveDisputeEvidence(ctx context.Context, disputeID string, fields map[string]string, updatedBy string) (*Evidence, error)
+	MarkDisputeEvidenceSubmitted(ctx context.Context, disputeID, submittedBy string) (*Evidence, error)
+	CreateDisputeEvidenceFile(ctx context.Context, file *EvidenceFile) (*EvidenceFile, error)
+	ListDisputeEvidenceFiles(ctx context.Context, disputeID string) ([]*EvidenceFile, error)
+	ListDisputesDueForEvidence(ctx context.Context, dueAfter, dueBefore time.Time) ([]*stripe.Dispute, error)
+	ClaimDisputeEvidenceReminder(ctx context.Context, disputeID string, dueBy time.Time) (bool, error)
+}
+
+// Provider retrieves disputes from the payment provider
+type Provider interface {
+	GetDispute(ctx context.Context, disputeID string) (*stripe.Dispute, error)
+}
+
+// EvidenceProvider uploads evidence files, submits evidence and concedes
+// disputes at the payment provider
+type EvidenceProvider interface {
+	UploadDisputeEvidenceFile(ctx context.Context, filename string, content io.Reader) (*stripe.DisputeFile, error)
+	UpdateDisputeEvidence(ctx context.Context, disputeID string, evidence map[string]string, submit bool) (*stripe.Dispute, error)
+	CloseDispute(ctx context.Context, disputeID string) (*stripe.Dispute, error)
+}
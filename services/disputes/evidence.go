@@ -0,0 +1,386 @@
+package disputes
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"apis/payments/services/events"
+	"apis/payments/services/stripe"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// EvidenceService stages dispute evidence, uploads evidence files, submits
+// evidence to the provider and reminds about approaching deadlines
+type EvidenceService struct {
+	store    EvidenceStore
+	disputes *Service
+	provider EvidenceProvider
+	emitter  *events.Emitter
+	config   *Config
+	tracer   trace.Tracer
+}
+
+// NewEvidenceService creates a new dispute evidence service
+func NewEvidenceService(store EvidenceStore, disputes *Service, provider EvidenceProvider, emitter *events.Emitter, config *Config) *EvidenceService {
+	if config == nil {
+		config = LoadConfig()
+	}
+
+	return &EvidenceService{
+		store:    store,
+		disputes: disputes,
+		provider: provider,
+		emitter:  emitter,
+		config:   config,
+		tracer:   otel.Tracer("payments.disputes"),
+	}
+}
+
+// Get returns the evidence staged for a dispute with its files and deadline
+func (s *EvidenceService) Get(ctx context.Context, disputeID string) (*Evidence, error) {
+	ctx, span := s.tracer.Start(ctx, "Get")
+	defer span.End()
+
+	dispute, err := s.disputes.Get(ctx, disputeID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.load(ctx, dispute)
+}
+
+// Update merges fields into a dispute's staged evidence. Fields are keyed by
+// Stripe's evidence field names; an empty value removes a field. File fields
+// take the ID of a file uploaded for the dispute.
+func (s *EvidenceService) Update(ctx context.Context, disputeID string, fields map[string]string, updatedBy string) (*Evidence, error) {
+	ctx, span := s.tracer.Start(ctx, "Update")
+	defer span.End()
+
+	dispute, err := s.respondable(ctx, disputeID)
+	if err != nil {
+		return nil, err
+	}
+
+	evidence, err := s.load(ctx, dispute)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := make(map[string]string, len(evidence.Fields)+len(fields))
+	for field, value := range evidence.Fields {
+		merged[field] = value
+	}
+	for field, value := range fields {
+		value = strings.TrimSpace(value)
+		switch {
+		case value == "":
+			delete(merged, field)
+		case textEvidenceFields[field]:
+			merged[field] = value
+		case fileEvidenceFields[field]:
+			file := findFile(evidence.Files, value)
+			if file == nil {
+				return nil, fmt.Errorf("%w: %s is not a file uploaded for dispute %s", ErrInvalidEvidence, value, disputeID)
+			}
+			merged[field] = file.ProviderFileID
+		default:
+			return nil, fmt.Errorf("%w: unknown evidence field %q", ErrInvalidEvidence, field)
+		}
+	}
+
+	if err := checkTextLength(merged); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.store.SaveDisputeEvidence(ctx, disputeID, merged, updatedBy); err != nil {
+		return nil, fmt.Errorf("failed to save dispute evidence: %w", err)
+	}
+
+	return s.load(ctx, dispute)
+}
+
+// Upload uploads an evidence file to the provider and records it against the
+// dispute. When the upload names a file field, the file is attached to it.
+func (s *EvidenceService) Upload(ctx context.Context, disputeID string, upload *Upload) (*EvidenceFile, error) {
+	ctx, span := s.tracer.Start(ctx, "Upload")
+	defer span.End()
+
+	if upload.Field != "" && !fileEvidenceFields[upload.Field] {
+		return nil, fmt.Errorf("%w: %q is not a file evidence field", ErrInvalidEvidence, upload.Field)
+	}
+
+	if _, err := s.respondable(ctx, disputeID); err != nil {
+		return nil, err
+	}
+
+	content, err := io.ReadAll(io.LimitReader(upload.Content, s.config.MaxFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read evidence file: %w", err)
+	}
+	if len(content) == 0 {
+		return nil, fmt.Errorf("%w: evidence file is empty", ErrInvalidEvidence)
+	}
+	if int64(len(content)) > s.config.MaxFileSize {
+		return nil, fmt.Errorf("%w: evidence files may be at most %d bytes", ErrInvalidEvidence, s.config.MaxFileSize)
+	}
+
+	contentType := http.DetectContentType(content)
+	if !evidenceContentTypes[contentType] {
+		return nil, fmt.Errorf("%w: evidence files must be PDF, JPEG or PNG, not %s", ErrInvalidEvidence, contentType)
+	}
+
+	filename := filepath.Base(upload.Filename)
+	if filename == "." || filename == string(filepath.Separator) {
+		filename = "evidence"
+	}
+
+	uploaded, err := s.provider.UploadDisputeEvidenceFile(ctx, filename, bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := s.store.CreateDisputeEvidenceFile(ctx, &EvidenceFile{
+		ID:             fmt.Sprintf("dfe_%s", uuid.New().String()),
+		DisputeID:      disputeID,
+		ProviderFileID: uploaded.ID,
+		Field:          upload.Field,
+		Filename:       filename,
+		ContentType:    contentType,
+		Size:           int64(len(content)),
+		UploadedBy:     upload.UploadedBy,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record evidence file: %w", err)
+	}
+
+	if upload.Field != "" {
+		if _, err := s.Update(ctx, disputeID, map[string]string{upload.Field: file.ID}, upload.UploadedBy); err != nil {
+			return nil, err
+		}
+	}
+
+	return file, nil
+}
+
+// Submit sends a dispute's staged evidence to the provider and submits it to
+// the bank. The provider's updated dispute is synced locally.
+func (s *EvidenceService) Submit(ctx context.Context, disputeID, submittedBy string) (*Evidence, error) {
+	ctx, span := s.tracer.Start(ctx, "Submit")
+	defer span.End()
+
+	dispute, err := s.respondable(ctx, disputeID)
+	if err != nil {
+		return nil, err
+	}
+
+	evidence, err := s.load(ctx, dispute)
+	if err != nil {
+		return nil, err
+	}
+	if len(evidence.Fields) == 0 {
+		return nil, ErrNoEvidence
+	}
+
+	updated, err := s.provider.UpdateDisputeEvidence(ctx, disputeID, evidence.Fields, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.disputes.Sync(ctx, updated, time.Now()); err != nil {
+		log.Printf("Failed to sync dispute %s after submitting evidence: %v", disputeID, err)
+		updated = dispute
+	}
+
+	if _, err := s.store.MarkDisputeEvidenceSubmitted(ctx, disputeID, submittedBy); err != nil {
+		return nil, fmt.Errorf("failed to record evidence submission: %w", err)
+	}
+
+	log.Printf("Evidence for dispute %s submitted by %s", disputeID, submittedBy)
+	return s.load(ctx, updated)
+}
+
+// Accept concedes a dispute that still awaits a response before its
+// deadline. The provider's closed dispute is synced locally.
+func (s *EvidenceService) Accept(ctx context.Context, disputeID, acceptedBy string) (*stripe.Dispute, error) {
+	ctx, span := s.tracer.Start(ctx, "Accept")
+	defer span.End()
+
+	if _, err := s.respondable(ctx, disputeID); err != nil {
+		return nil, err
+	}
+
+	closed, err := s.provider.CloseDispute(ctx, disputeID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.disputes.Sync(ctx, closed, time.Now()); err != nil {
+		log.Printf("Failed to sync dispute %s after accepting it: %v", disputeID, err)
+	}
+
+	log.Printf("Dispute %s accepted by %s", disputeID, acceptedBy)
+	return closed, nil
+}
+
+// SendReminders emits an evidence_due_soon event for every dispute still
+// needing a response whose deadline falls within the reminder lead. Each
+// deadline is reminded about once.
+func (s *EvidenceService) SendReminders(ctx context.Context, now time.Time) (int, error) {
+	ctx, span := s.tracer.Start(ctx, "SendReminders")
+	defer span.End()
+
+	due, err := s.store.ListDisputesDueForEvidence(ctx, now, now.Add(s.config.ReminderLead))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list disputes due for evidence: %w", err)
+	}
+
+	sent := 0
+	for _, dispute := range due {
+		if dispute.EvidenceDetails == nil || dispute.EvidenceDetails.DueBy == nil {
+			continue
+		}
+		dueBy := *dispute.EvidenceDetails.DueBy
+
+		claimed, err := s.store.ClaimDisputeEvidenceReminder(ctx, dispute.ID, dueBy)
+		if err != nil {
+			return sent, fmt.Errorf("failed to claim evidence reminder: %w", err)
+		}
+		if !claimed {
+			continue
+		}
+
+		staged := false
+		if evidence, err := s.store.GetDisputeEvidence(ctx, dispute.ID); err == nil {
+			staged = len(evidence.Fields) > 0
+		}
+
+		reminder := &Reminder{
+			DisputeID:      dispute.ID,
+			ChargeID:       dispute.ChargeID,
+			Amount:         dispute.Amount,
+			Currency:       dispute.Currency,
+			Reason:         dispute.Reason,
+			Status:         dispute.Status,
+			DueBy:          dueBy,
+			StagedEvidence: staged,
+		}
+		if err := s.emitter.Emit(ctx, "disputes", EventEvidenceDueSoon, dispute.ID, reminder); err != nil {
+			log.Printf("Failed to emit %s for dispute %s: %v", EventEvidenceDueSoon, dispute.ID, err)
+			continue
+		}
+		sent++
+	}
+
+	return sent, nil
+}
+
+// Start sends evidence reminders every configured interval until the
+// returned stop function is called
+func (s *EvidenceService) Start() (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(s.config.ReminderInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := s.SendReminders(context.Background(), time.Now()); err != nil {
+					log.Printf("Dispute evidence reminder run failed: %v", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// respondable loads a dispute that can still take evidence
+func (s *EvidenceService) respondable(ctx context.Context, disputeID string) (*stripe.Dispute, error) {
+	dispute, err := s.disputes.Get(ctx, disputeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !respondableStatuses[dispute.Status] {
+		return nil, fmt.Errorf("%w: dispute is %s", ErrDisputeClosed, dispute.Status)
+	}
+	if details := dispute.EvidenceDetails; details != nil {
+		if details.PastDue || (details.DueBy != nil && time.Now().After(*details.DueBy)) {
+			return nil, ErrEvidencePastDue
+		}
+	}
+
+	return dispute, nil
+}
+
+// load assembles a dispute's staged evidence, files and deadline
+func (s *EvidenceService) load(ctx context.Context, dispute *stripe.Dispute) (*Evidence, error) {
+	evidence, err := s.store.GetDisputeEvidence(ctx, dispute.ID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		evidence = &Evidence{DisputeID: dispute.ID}
+	}
+	if evidence.Fields == nil {
+		evidence.Fields = make(map[string]string)
+	}
+
+	files, err := s.store.ListDisputeEvidenceFiles(ctx, dispute.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list evidence files: %w", err)
+	}
+	evidence.Files = files
+
+	evidence.Status = dispute.Status
+	if details := dispute.EvidenceDetails; details != nil {
+		evidence.DueBy = details.DueBy
+		evidence.PastDue = details.PastDue
+	}
+
+	return evidence, nil
+}
+
+// findFile finds a dispute's evidence file by its ID or provider file ID
+func findFile(files []*EvidenceFile, id string) *EvidenceFile {
+	for _, file := range files {
+		if file.ID == id || file.ProviderFileID == id {
+			return file
+		}
+	}
+	return nil
+}
+
+// checkTextLength enforces the provider's limit on combined text evidence
+func checkTextLength(fields map[string]string) error {
+	total := 0
+	for field, value := range fields {
+		if textEvidenceFields[field] {
+			total += len(value)
+		}
+	}
+	if total > maxEvidenceText {
+		return fmt.Errorf("%w: text evidence may total at most %d characters", ErrInvalidEvidence, maxEvidenceText)
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package disputes
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"apis/payments/services/stripe"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 500
+)
+
+// Service keeps a local copy of every dispute in sync with the provider
+type Service struct {
+	store    Store
+	provider Provider
+	tracer   trace.Tracer
+}
+
+// NewService creates a new dispute service
+func NewService(store Store, provider Provider) *Service {
+	return &Service{
+		store:    store,
+		provider: provider,
+		tracer:   otel.Tracer("payments.disputes"),
+	}
+}
+
+// Sync stores the provider's view of a dispute as of syncedAt
+func (s *Service) Sync(ctx context.Context, dispute *stripe.Dispute, syncedAt time.Time) error {
+	ctx, span := s.tracer.Start(ctx, "Sync")
+	defer span.End()
+
+	return s.store.UpsertDispute(ctx, dispute, syncedAt)
+}
+
+// Get returns a dispute, fetching it from the provider if it has not been
+// synced yet
+func (s *Service) Get(ctx context.Context, disputeID string) (*stripe.Dispute, error) {
+	ctx, span := s.tracer.Start(ctx, "Get")
+	defer span.End()
+
+	dispute, err := s.store.GetDispute(ctx, disputeID)
+	if err == nil {
+		return dispute, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	dispute, err = s.provider.GetDispute(ctx, disputeID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.UpsertDispute(ctx, dispute, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return dispute, nil
+}
+
+// List returns synced disputes, newest first
+func (s *Service) List(ctx context.Context, filter Filter) ([]*stripe.Dispute, error) {
+	ctx, span := s.tracer.Start(ctx, "List")
+	defer span.End()
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultListLimit
+	}
+	if filter.Limit > maxListLimit {
+		filter.Limit = maxListLimit
+	}
+
+	return s.store.ListDisputes(ctx, filter)
+}
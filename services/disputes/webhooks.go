@@ -0,0 +1,35 @@
+package disputes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"apis/payments/services/stripe"
+
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// disputeEvents are the provider events that carry a dispute's latest state
+var disputeEvents = []stripego.EventType{
+	stripego.EventTypeChargeDisputeCreated,
+	stripego.EventTypeChargeDisputeUpdated,
+	stripego.EventTypeChargeDisputeClosed,
+	stripego.EventTypeChargeDisputeFundsWithdrawn,
+	stripego.EventTypeChargeDisputeFundsReinstated,
+}
+
+// RegisterWebhookHandlers syncs disputes from every dispute event
+func (s *Service) RegisterWebhookHandlers(webhooks *stripe.WebhookService) {
+	for _, eventType := range disputeEvents {
+		webhooks.On(eventType, func(ctx context.Context, event stripego.Event) error {
+			var dispute stripego.Dispute
+			if err := json.Unmarshal(event.Data.Raw, &dispute); err != nil {
+				return fmt.Errorf("failed to parse dispute: %w", err)
+			}
+
+			return s.Sync(ctx, stripe.ConvertDispute(&dispute), time.Unix(event.Created, 0).UTC())
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package documents
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kinds of documents
+const (
+	KindInvoice = "invoice"
+	KindReceipt = "receipt"
+)
+
+var (
+	// ErrInvalidTemplate is returned when saving an invalid document template
+	ErrInvalidTemplate = errors.New("invalid document template")
+	// ErrUnsupportedLanguage is returned when rendering in a language
+	// documents are not translated to
+	ErrUnsupportedLanguage = errors.New("unsupported document language")
+	// ErrStorageUnavailable is returned when publishing documents while no
+	// object storage is configured
+	ErrStorageUnavailable = errors.New("document storage is not configured")
+)
+
+// Template brands a tenant's documents of one kind
+type Template struct {
+	TenantID     string    `json:"tenant_id"`
+	Kind         string    `json:"kind"`
+	BusinessName string    `json:"business_name,omitempty"`
+	Address      string    `json:"address,omitempty"`      // Printed under the business name, one line per line
+	TaxID        string    `json:"tax_id,omitempty"`       // e.g. a VAT number
+	AccentColor  string    `json:"accent_color,omitempty"` // #RRGGBB of the header band and rules
+	Footer       string    `json:"footer,omitempty"`
+	Language     string    `json:"language,omitempty"` // Used when a document is requested in no language
+	CreatedAt    time.Time `json:"created_at,omitempty"`
+	UpdatedAt    time.Time `json:"updated_at,omitempty"`
+}
+
+// Content is what a document shows. Amounts are in the currency's minor
+// unit.
+type Content struct {
+	Kind                string
+	SourceID            string // The invoice or charge the document is rendered from
+	Number              string
+	Status              string
+	Currency            string
+	IssuedAt            time.Time
+	DueAt               *time.Time
+	CustomerName        string
+	CustomerEmail       string
+	Description         string
+	Lines               []Line
+	Subtotal            int64
+	Taxes               []TaxLine
+	Total               int64
+	AmountPaid          int64
+	AmountDue           int64
+	AmountRefunded      int64
+	PaymentMethod       string
+	StatementDescriptor string
+	// BusinessName and SupportEmail are used when the template names no
+	// business, and printed in the footer
+	BusinessName string
+	SupportEmail string
+}
+
+// Line is a line item of a document
+type Line struct {
+	Description string
+	Quantity    int64
+	UnitAmount  int64 // Zero when the line has no unit price
+	Amount      int64
+}
+
+// TaxLine is the tax charged at one rate
+type TaxLine struct {
+	Name       string // e.g. "VAT"; printed as Tax when empty
+	Percentage float64
+	Inclusive  bool // Already included in the line amounts
+	Amount     int64
+}
+
+// Document is a rendered PDF kept in object storage
+type Document struct {
+	ID           string     `json:"id"`
+	TenantID     string     `json:"tenant_id"`
+	Kind         string     `json:"kind"`
+	SourceID     string     `json:"source_id"`
+	Language     string     `json:"language"`
+	ObjectKey    string     `json:"-"`
+	Checksum     string     `json:"checksum"` // SHA-256 of the PDF
+	Size         int64      `json:"size"`
+	URL          string     `json:"url,omitempty"` // Signed download URL
+	URLExpiresAt *time.Time `json:"url_expires_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"` // When the document was last rendered differently
+}
+
+// Config controls where documents are stored and how long download URLs
+// last. Documents are stored once a bucket is configured.
+type Config struct {
+	Endpoint        string // e.g. https://s3.amazonaws.com, or a MinIO URL
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	PathStyle       bool   // Address the bucket in the path rather than the host name
+	Prefix          string // Prepended to object keys
+	URLTTL          time.Duration
+}
+
+// maxURLTTL is the longest SigV4 presigned URLs are valid
+const maxURLTTL = 7 * 24 * time.Hour
+
+// LoadConfig loads the document storage configuration from environment variables
+func LoadConfig() *Config {
+	config := &Config{
+		Endpoint:        "https://s3.amazonaws.com",
+		Region:          "us-east-1",
+		Bucket:          os.Getenv("DOCUMENT_STORAGE_BUCKET"),
+		AccessKeyID:     os.Getenv("DOCUMENT_STORAGE_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("DOCUMENT_STORAGE_SECRET_ACCESS_KEY"),
+		PathStyle:       true,
+		Prefix:          "documents/",
+		URLTTL:          15 * time.Minute,
+	}
+
+	if endpoint := os.Getenv("DOCUMENT_STORAGE_ENDPOINT"); endpoint != "" {
+		config.Endpoint = strings.TrimRight(endpoint, "/")
+	}
+	if region := os.Getenv("DOCUMENT_STORAGE_REGION"); region != "" {
+		config.Region = region
+	}
+	if pathStyle, err := strconv.ParseBool(os.Getenv("DOCUMENT_STORAGE_PATH_STYLE")); err == nil {
+		config.PathStyle = pathStyle
+	}
+	if prefix, ok := os.LookupEnv("DOCUMENT_STORAGE_PREFIX"); ok {
+		config.Prefix = prefix
+	}
+	if minutes, err := strconv.Atoi(os.Getenv("DOCUMENT_URL_TTL_MINUTES")); err == nil && minutes > 0 {
+		config.URLTTL = min(time.Duration(minutes)*time.Minute, maxURLTTL)
+	}
+
+	return config
+}
+
+// Enabled reports whether documents can be stored
+func (c *Config) Enabled() bool {
+	return c.Bucket != ""
+}
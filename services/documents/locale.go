@@ -0,0 +1,169 @@
+package documents
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// DefaultLanguage is used when neither the request nor the template names a
+// language
+const DefaultLanguage = "en"
+
+// Label keys printed on documents
+const (
+	labelInvoice             = "invoice"
+	labelReceipt             = "receipt"
+	labelNumber              = "number"
+	labelDate                = "date"
+	labelDueDate             = "due_date"
+	labelStatus              = "status"
+	labelBillTo              = "bill_to"
+	labelDescription         = "description"
+	labelQuantity            = "quantity"
+	labelUnitPrice           = "unit_price"
+	labelAmount              = "amount"
+	labelSubtotal            = "subtotal"
+	labelTax                 = "tax"
+	labelIncluded            = "included"
+	labelTotal               = "total"
+	labelAmountPaid          = "amount_paid"
+	labelAmountDue           = "amount_due"
+	labelAmountRefunded      = "amount_refunded"
+	labelPaymentMethod       = "payment_method"
+	labelStatementDescriptor = "statement_descriptor"
+	labelTaxID               = "tax_id"
+	labelPayment             = "payment"
+	labelContact             = "contact" // Takes the support email
+	labelPage                = "page"    // Takes the page number and count
+)
+
+// locale is how documents are written in a language
+type locale struct {
+	labels   map[string]string
+	statuses map[string]string
+	months   [12]string
+	date     func(day int, month string, year int) string
+}
+
+// locales are the languages documents are translated to
+var locales = map[string]*locale{
+	"en": {
+		labels: map[string]string{
+			labelInvoice: "Invoice", labelReceipt: "Receipt", labelNumber: "Number", labelDate: "Date",
+			labelDueDate: "Due date", labelStatus: "Status", labelBillTo: "Bill to", labelDescription: "Description",
+			labelQuantity: "Qty", labelUnitPrice: "Unit price", labelAmount: "Amount", labelSubtotal: "Subtotal",
+			labelTax: "Tax", labelIncluded: "incl.", labelTotal: "Total", labelAmountPaid: "Amount paid",
+			labelAmountDue: "Amount due", labelAmountRefunded: "Amount refunded", labelPaymentMethod: "Payment method",
+			labelStatementDescriptor: "Statement descriptor", labelTaxID: "Tax ID", labelPayment: "Payment",
+			labelContact: "Questions? Contact %s", labelPage: "Page %d of %d",
+		},
+		statuses: map[string]string{
+			"draft": "Draft", "open": "Open", "paid": "Paid", "void": "Void", "uncollectible": "Uncollectible",
+			"succeeded": "Paid", "pending": "Pending", "failed": "Failed", "refunded": "Refunded",
+		},
+		months: [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		date: func(day int, month string, year int) string {
+			return fmt.Sprintf("%s %d, %d", month, day, year)
+		},
+	},
+	"es": {
+		labels: map[string]string{
+			labelInvoice: "Factura", labelReceipt: "Recibo", labelNumber: "Número", labelDate: "Fecha",
+			labelDueDate: "Vencimiento", labelStatus: "Estado", labelBillTo: "Facturar a", labelDescription: "Descripción",
+			labelQuantity: "Cant.", labelUnitPrice: "Precio unitario", labelAmount: "Importe", labelSubtotal: "Subtotal",
+			labelTax: "Impuesto", labelIncluded: "incl.", labelTotal: "Total", labelAmountPaid: "Importe pagado",
+			labelAmountDue: "Importe pendiente", labelAmountRefunded: "Importe reembolsado", labelPaymentMethod: "Método de pago",
+			labelStatementDescriptor: "Descriptor del extracto", labelTaxID: "NIF", labelPayment: "Pago",
+			labelContact: "¿Preguntas? Escriba a %s", labelPage: "Página %d de %d",
+		},
+		statuses: map[string]string{
+			"draft": "Borrador", "open": "Abierta", "paid": "Pagada", "void": "Anulada", "uncollectible": "Incobrable",
+			"succeeded": "Pagado", "pending": "Pendiente", "failed": "Fallido", "refunded": "Reembolsado",
+		},
+		months: [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		date: func(day int, month string, year int) string {
+			return fmt.Sprintf("%d de %s de %d", day, month, year)
+		},
+	},
+	"fr": {
+		labels: map[string]string{
+			labelInvoice: "Facture", labelReceipt: "Reçu", labelNumber: "Numéro", labelDate: "Date",
+			labelDueDate: "Échéance", labelStatus: "Statut", labelBillTo: "Facturé à", labelDescription: "Description",
+			labelQuantity: "Qté", labelUnitPrice: "Prix unitaire", labelAmount: "Montant", labelSubtotal: "Sous-total",
+			labelTax: "Taxe", labelIncluded: "incl.", labelTotal: "Total", labelAmountPaid: "Montant payé",
+			labelAmountDue: "Montant dû", labelAmountRefunded: "Montant remboursé", labelPaymentMethod: "Moyen de paiement",
+			labelStatementDescriptor: "Libellé du relevé", labelTaxID: "N° de TVA", labelPayment: "Paiement",
+			labelContact: "Des questions ? Écrivez à %s", labelPage: "Page %d sur %d",
+		},
+		statuses: map[string]string{
+			"draft": "Brouillon", "open": "Ouverte", "paid": "Payée", "void": "Annulée", "uncollectible": "Irrécouvrable",
+			"succeeded": "Payé", "pending": "En attente", "failed": "Échoué", "refunded": "Remboursé",
+		},
+		months: [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		date: func(day int, month string, year int) string {
+			return fmt.Sprintf("%d %s %d", day, month, year)
+		},
+	},
+	"de": {
+		labels: map[string]string{
+			labelInvoice: "Rechnung", labelReceipt: "Quittung", labelNumber: "Nummer", labelDate: "Datum",
+			labelDueDate: "Fällig am", labelStatus: "Status", labelBillTo: "Rechnung an", labelDescription: "Beschreibung",
+			labelQuantity: "Menge", labelUnitPrice: "Einzelpreis", labelAmount: "Betrag", labelSubtotal: "Zwischensumme",
+			labelTax: "Steuer", labelIncluded: "inkl.", labelTotal: "Gesamt", labelAmountPaid: "Bezahlt",
+			labelAmountDue: "Offener Betrag", labelAmountRefunded: "Erstattet", labelPaymentMethod: "Zahlungsmethode",
+			labelStatementDescriptor: "Verwendungszweck", labelTaxID: "USt-IdNr.", labelPayment: "Zahlung",
+			labelContact: "Fragen? Schreiben Sie an %s", labelPage: "Seite %d von %d",
+		},
+		statuses: map[string]string{
+			"draft": "Entwurf", "open": "Offen", "paid": "Bezahlt", "void": "Storniert", "uncollectible": "Uneinbringlich",
+			"succeeded": "Bezahlt", "pending": "Ausstehend", "failed": "Fehlgeschlagen", "refunded": "Erstattet",
+		},
+		months: [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		date: func(day int, month string, year int) string {
+			return fmt.Sprintf("%d. %s %d", day, month, year)
+		},
+	},
+}
+
+// Languages returns the languages documents are translated to
+func Languages() []string {
+	languages := make([]string, 0, len(locales))
+	for language := range locales {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// SupportsLanguage reports whether documents are translated to a language
+func SupportsLanguage(language string) bool {
+	_, ok := locales[language]
+	return ok
+}
+
+// label returns a label in the locale's language
+func (l *locale) label(key string) string {
+	return l.labels[key]
+}
+
+// status returns a status in the locale's language, or as it is when it
+// has no translation
+func (l *locale) status(status string) string {
+	if translated, ok := l.statuses[status]; ok {
+		return translated
+	}
+	return status
+}
+
+// formatDate writes a date in the locale's long form, in UTC
+func (l *locale) formatDate(t time.Time) string {
+	t = t.UTC()
+	return l.date(t.Day(), l.months[t.Month()-1], t.Year())
+}
+
+// formatPercentage writes a tax rate, e.g. 20% or 7.5%
+func formatPercentage(percentage float64) string {
+	return strconv.FormatFloat(percentage, 'f', -1, 64) + "%"
+}
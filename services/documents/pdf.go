@@ -0,0 +1,202 @@
+package documents
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// A4 page size in points
+const (
+	PageWidth  = 595
+	PageHeight = 842
+)
+
+// Fonts documents are set in, both WinAnsi encoded
+const (
+	FontRegular = "F1" // Helvetica
+	FontBold    = "F2" // Helvetica-Bold
+)
+
+// Color is an RGB color with components from 0 to 1
+type Color struct {
+	R, G, B float64
+}
+
+// Black is the default text color
+var Black = Color{}
+
+// PDF lays text and rules out on A4 pages. Output is deterministic, so the
+// same document always renders to the same bytes.
+type PDF struct {
+	pages   []*bytes.Buffer
+	current int
+}
+
+// NewPDF creates a PDF with one empty page
+func NewPDF() *PDF {
+	return &PDF{pages: []*bytes.Buffer{{}}}
+}
+
+// AddPage starts a new page and draws on it
+func (p *PDF) AddPage() {
+	p.pages = append(p.pages, &bytes.Buffer{})
+	p.current = len(p.pages) - 1
+}
+
+// Pages returns the number of pages
+func (p *PDF) Pages() int {
+	return len(p.pages)
+}
+
+// SetPage draws on an earlier page, numbered from 0
+func (p *PDF) SetPage(page int) {
+	p.current = page
+}
+
+// SetColor sets the color of text, rules and boxes drawn next
+func (p *PDF) SetColor(color Color) {
+	fmt.Fprintf(p.pages[p.current], "%s %s %s rg %s %s %s RG\n",
+		number(color.R), number(color.G), number(color.B), number(color.R), number(color.G), number(color.B))
+}
+
+// Text draws text with its baseline starting at x, y
+func (p *PDF) Text(font string, size int, x float64, y int, text string) {
+	fmt.Fprintf(p.pages[p.current], "BT /%s %d Tf %s %d Td (%s) Tj ET\n", font, size, number(x), y, pdfText(text))
+}
+
+// TextRight draws text ending at x
+func (p *PDF) TextRight(font string, size int, x float64, y int, text string) {
+	p.Text(font, size, x-TextWidth(font, size, text), y, text)
+}
+
+// Rule draws a horizontal line from x1 to x2
+func (p *PDF) Rule(x1, x2 float64, y int, width float64) {
+	fmt.Fprintf(p.pages[p.current], "%s w %s %d m %s %d l S\n", number(width), number(x1), y, number(x2), y)
+}
+
+// Box fills a rectangle whose bottom left corner is at x, y
+func (p *PDF) Box(x float64, y int, width, height float64) {
+	fmt.Fprintf(p.pages[p.current], "%s %d %s %s re f\n", number(x), y, number(width), number(height))
+}
+
+// Bytes serializes the document
+func (p *PDF) Bytes() []byte {
+	// Catalog, page tree and fonts come first, then each page and its content
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+	}
+	kids := make([]string, len(p.pages))
+	for i, content := range p.pages {
+		page := len(objects) + 1
+		kids[i] = fmt.Sprintf("%d 0 R", page)
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /%s 3 0 R /%s 4 0 R >> >> /Contents %d 0 R >>",
+				PageWidth, PageHeight, FontRegular, FontBold, page+1),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+		)
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(p.pages))
+
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = pdf.Len()
+		fmt.Fprintf(&pdf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := pdf.Len()
+	fmt.Fprintf(&pdf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&pdf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&pdf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return pdf.Bytes()
+}
+
+// TextWidth returns the width of text set in a font, in points
+func TextWidth(font string, size int, text string) float64 {
+	widths := &helveticaWidths
+	if font == FontBold {
+		widths = &helveticaBoldWidths
+	}
+
+	units := 0
+	for _, r := range text {
+		if r >= 32 && r < 127 {
+			units += int(widths[r-32])
+		} else {
+			units += 556 // Close to the width of most accented letters
+		}
+	}
+	return float64(units) * float64(size) / 1000
+}
+
+// Fit shortens text with an ellipsis until it fits width
+func Fit(font string, size int, text string, width float64) string {
+	if TextWidth(font, size, text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		if shortened := strings.TrimRight(string(runes), " ") + "..."; TextWidth(font, size, shortened) <= width {
+			return shortened
+		}
+	}
+	return ""
+}
+
+// number formats a coordinate with at most two decimals
+func number(value float64) string {
+	return strconv.FormatFloat(math.Round(value*100)/100, 'f', -1, 64)
+}
+
+// pdfText encodes text for a PDF string in WinAnsiEncoding, escaping the
+// characters strings delimit with and replacing what the encoding lacks
+func pdfText(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '€':
+			b.WriteString(`\200`)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, `\%03o`, r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// Character widths of printable ASCII, from the fonts' Adobe metrics
+var (
+	helveticaWidths = [95]uint16{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	}
+	helveticaBoldWidths = [95]uint16{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	}
+)
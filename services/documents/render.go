@@ -0,0 +1,291 @@
+package documents
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"apis/payments/services/money"
+)
+
+// Page layout, in points
+const (
+	marginLeft   = 56
+	marginRight  = PageWidth - 56
+	marginBottom = 80
+	pageTop      = 790
+	footerTop    = 52
+
+	// Columns of the line item table, right aligned but for the description
+	columnQuantity   = 380
+	columnUnitPrice  = 460
+	descriptionWidth = 270
+	// Totals are labelled from here and right aligned to the margin
+	totalsLeft = 340
+)
+
+// Render lays a document out as an A4 PDF, branded with a template and
+// written in one of the supported languages
+func Render(content *Content, template *Template, language string) ([]byte, error) {
+	locale, ok := locales[language]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedLanguage, language)
+	}
+
+	r := &renderer{pdf: NewPDF(), locale: locale, content: content, template: template, accent: Black}
+	if color, ok := parseColor(template.AccentColor); ok {
+		r.accent = color
+	}
+
+	r.header()
+	r.parties()
+	r.lineItems()
+	r.totals()
+	r.details()
+	r.footer()
+
+	return r.pdf.Bytes(), nil
+}
+
+// renderer lays a document out from the top of its first page down
+type renderer struct {
+	pdf      *PDF
+	locale   *locale
+	content  *Content
+	template *Template
+	accent   Color
+	y        int
+}
+
+// header draws the business, the document's title and its number and dates
+func (r *renderer) header() {
+	if r.template.AccentColor != "" {
+		r.pdf.SetColor(r.accent)
+		r.pdf.Box(0, PageHeight-14, PageWidth, 14)
+		r.pdf.SetColor(Black)
+	}
+
+	// The business on the left
+	left := 780
+	businessName := r.template.BusinessName
+	if businessName == "" {
+		businessName = r.content.BusinessName
+	}
+	if businessName != "" {
+		r.pdf.Text(FontBold, 16, marginLeft, left, Fit(FontBold, 16, businessName, totalsLeft-marginLeft-20))
+		left -= 18
+	}
+	for _, line := range strings.Split(r.template.Address, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			r.pdf.Text(FontRegular, 9, marginLeft, left, line)
+			left -= 12
+		}
+	}
+	if r.template.TaxID != "" {
+		r.pdf.Text(FontRegular, 9, marginLeft, left, r.locale.label(labelTaxID)+": "+r.template.TaxID)
+		left -= 12
+	}
+
+	// The title and what identifies the document on the right
+	title := r.locale.label(labelInvoice)
+	if r.content.Kind == KindReceipt {
+		title = r.locale.label(labelReceipt)
+	}
+	r.pdf.SetColor(r.accent)
+	r.pdf.TextRight(FontBold, 20, marginRight, 780, title)
+	r.pdf.SetColor(Black)
+
+	right := 756
+	row := func(label, value string) {
+		if value == "" {
+			return
+		}
+		r.pdf.Text(FontRegular, 9, totalsLeft, right, label)
+		r.pdf.TextRight(FontRegular, 9, marginRight, right, value)
+		right -= 13
+	}
+	row(r.locale.label(labelNumber), r.content.Number)
+	if !r.content.IssuedAt.IsZero() {
+		row(r.locale.label(labelDate), r.locale.formatDate(r.content.IssuedAt))
+	}
+	if r.content.DueAt != nil {
+		row(r.locale.label(labelDueDate), r.locale.formatDate(*r.content.DueAt))
+	}
+	if r.content.Status != "" {
+		row(r.locale.label(labelStatus), r.locale.status(r.content.Status))
+	}
+
+	r.y = min(left, right) - 24
+}
+
+// parties draws who the document is for and what it is about
+func (r *renderer) parties() {
+	if r.content.CustomerName != "" || r.content.CustomerEmail != "" {
+		r.pdf.Text(FontBold, 10, marginLeft, r.y, r.locale.label(labelBillTo))
+		r.y -= 14
+		for _, line := range []string{r.content.CustomerName, r.content.CustomerEmail} {
+			if line != "" {
+				r.pdf.Text(FontRegular, 10, marginLeft, r.y, line)
+				r.y -= 13
+			}
+		}
+		r.y -= 12
+	}
+
+	if r.content.Description != "" {
+		r.pdf.Text(FontRegular, 10, marginLeft, r.y, Fit(FontRegular, 10, r.content.Description, marginRight-marginLeft))
+		r.y -= 24
+	}
+}
+
+// lineItems draws the table of line items, continuing on new pages
+func (r *renderer) lineItems() {
+	lines := r.content.Lines
+	if len(lines) == 0 {
+		lines = []Line{{Description: r.locale.label(labelPayment), Quantity: 1, Amount: r.content.Total}}
+	}
+
+	r.tableHeader()
+	for _, line := range lines {
+		if r.y < marginBottom {
+			r.newPage()
+			r.tableHeader()
+		}
+
+		description := line.Description
+		if description == "" {
+			description = r.locale.label(labelPayment)
+		}
+		r.pdf.Text(FontRegular, 10, marginLeft, r.y, Fit(FontRegular, 10, description, descriptionWidth))
+		if line.Quantity > 0 {
+			r.pdf.TextRight(FontRegular, 10, columnQuantity, r.y, strconv.FormatInt(line.Quantity, 10))
+		}
+		if line.UnitAmount > 0 {
+			r.pdf.TextRight(FontRegular, 10, columnUnitPrice, r.y, r.amount(line.UnitAmount))
+		}
+		r.pdf.TextRight(FontRegular, 10, marginRight, r.y, r.amount(line.Amount))
+		r.y -= 18
+	}
+	r.y -= 6
+}
+
+// tableHeader draws the labels of the line item columns
+func (r *renderer) tableHeader() {
+	r.pdf.Text(FontBold, 9, marginLeft, r.y, r.locale.label(labelDescription))
+	r.pdf.TextRight(FontBold, 9, columnQuantity, r.y, r.locale.label(labelQuantity))
+	r.pdf.TextRight(FontBold, 9, columnUnitPrice, r.y, r.locale.label(labelUnitPrice))
+	r.pdf.TextRight(FontBold, 9, marginRight, r.y, r.locale.label(labelAmount))
+	r.pdf.SetColor(r.accent)
+	r.pdf.Rule(marginLeft, marginRight, r.y-6, 0.75)
+	r.pdf.SetColor(Black)
+	r.y -= 22
+}
+
+// totals draws the subtotal, each tax and what was paid and is due
+func (r *renderer) totals() {
+	if r.y-16*(len(r.content.Taxes)+5) < marginBottom {
+		r.newPage()
+	}
+
+	r.pdf.Rule(totalsLeft, marginRight, r.y+10, 0.5)
+	row := func(font, label string, amount int64) {
+		r.pdf.Text(font, 10, totalsLeft, r.y, label)
+		r.pdf.TextRight(font, 10, marginRight, r.y, r.amount(amount))
+		r.y -= 16
+	}
+
+	row(FontRegular, r.locale.label(labelSubtotal), r.content.Subtotal)
+	for _, tax := range r.content.Taxes {
+		name := tax.Name
+		if name == "" {
+			name = r.locale.label(labelTax)
+		}
+		var details []string
+		if tax.Percentage > 0 {
+			details = append(details, formatPercentage(tax.Percentage))
+		}
+		if tax.Inclusive {
+			details = append(details, r.locale.label(labelIncluded))
+		}
+		if len(details) > 0 {
+			name += " (" + strings.Join(details, ", ") + ")"
+		}
+		row(FontRegular, name, tax.Amount)
+	}
+	row(FontBold, r.locale.label(labelTotal), r.content.Total)
+
+	if r.content.Kind == KindInvoice || r.content.AmountPaid > 0 {
+		row(FontRegular, r.locale.label(labelAmountPaid), r.content.AmountPaid)
+	}
+	if r.content.Kind == KindInvoice {
+		row(FontBold, r.locale.label(labelAmountDue), r.content.AmountDue)
+	}
+	if r.content.AmountRefunded > 0 {
+		row(FontRegular, r.locale.label(labelAmountRefunded), r.content.AmountRefunded)
+	}
+	r.y -= 12
+}
+
+// details draws how a receipt was paid
+func (r *renderer) details() {
+	row := func(label, value string) {
+		if value == "" {
+			return
+		}
+		if r.y < marginBottom {
+			r.newPage()
+		}
+		r.pdf.Text(FontRegular, 9, marginLeft, r.y, label)
+		r.pdf.Text(FontRegular, 9, marginLeft+130, r.y, value)
+		r.y -= 13
+	}
+	row(r.locale.label(labelPaymentMethod), r.content.PaymentMethod)
+	row(r.locale.label(labelStatementDescriptor), r.content.StatementDescriptor)
+}
+
+// footer draws the template's footer, the support contact and page numbers
+// on every page
+func (r *renderer) footer() {
+	pages := r.pdf.Pages()
+	for page := 0; page < pages; page++ {
+		r.pdf.SetPage(page)
+		y := footerTop
+		if r.template.Footer != "" {
+			r.pdf.Text(FontRegular, 8, marginLeft, y, Fit(FontRegular, 8, r.template.Footer, marginRight-marginLeft))
+			y -= 12
+		}
+		if r.content.SupportEmail != "" {
+			r.pdf.Text(FontRegular, 8, marginLeft, y, fmt.Sprintf(r.locale.label(labelContact), r.content.SupportEmail))
+		}
+		if pages > 1 {
+			r.pdf.TextRight(FontRegular, 8, marginRight, footerTop-12, fmt.Sprintf(r.locale.label(labelPage), page+1, pages))
+		}
+	}
+}
+
+// newPage continues the document on a new page
+func (r *renderer) newPage() {
+	r.pdf.AddPage()
+	r.y = pageTop
+}
+
+// amount formats an amount in the document's currency
+func (r *renderer) amount(minor int64) string {
+	return money.Describe(minor, r.content.Currency).Formatted
+}
+
+// parseColor parses a #RRGGBB color
+func parseColor(value string) (Color, bool) {
+	if len(value) != 7 || value[0] != '#' {
+		return Color{}, false
+	}
+	rgb, err := strconv.ParseUint(value[1:], 16, 32)
+	if err != nil {
+		return Color{}, false
+	}
+	return Color{
+		R: float64(rgb>>16&0xff) / 255,
+		G: float64(rgb>>8&0xff) / 255,
+		B: float64(rgb&0xff) / 255,
+	}, true
+}
@@ -0,0 +1,210 @@
+package documents
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Store persists document templates and the documents rendered. Missing
+// templates and documents return sql.ErrNoRows.
+type Store interface {
+	GetDocumentTemplate(ctx context.Context, tenantID, kind string) (*Template, error)
+	UpsertDocumentTemplate(ctx context.Context, template *Template) (*Template, error)
+	DeleteDocumentTemplate(ctx context.Context, tenantID, kind string) (bool, error)
+	GetDocument(ctx context.Context, tenantID, id string) (*Document, error)
+	GetDocumentBySource(ctx context.Context, tenantID, kind, sourceID, language string) (*Document, error)
+	UpsertDocument(ctx context.Context, document *Document) (*Document, error)
+}
+
+// Service renders branded invoices and receipts, keeps them in object
+// storage and signs URLs to download them
+type Service struct {
+	store   Store
+	objects ObjectStore
+	config  *Config
+	tracer  trace.Tracer
+}
+
+// NewService creates a new document service. Documents can only be
+// published when objects is not nil.
+func NewService(store Store, objects ObjectStore, config *Config) *Service {
+	return &Service{
+		store:   store,
+		objects: objects,
+		config:  config,
+		tracer:  otel.Tracer("payments.documents"),
+	}
+}
+
+// Enabled reports whether documents can be published
+func (s *Service) Enabled() bool {
+	return s.objects != nil
+}
+
+// Template returns a tenant's template for a kind of document, or an empty
+// one when it has saved none
+func (s *Service) Template(ctx context.Context, tenantID, kind string) (*Template, error) {
+	ctx, span := s.tracer.Start(ctx, "Template")
+	defer span.End()
+
+	if err := checkKind(kind); err != nil {
+		return nil, err
+	}
+
+	template, err := s.store.GetDocumentTemplate(ctx, tenantID, kind)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &Template{TenantID: tenantID, Kind: kind}, nil
+	}
+	return template, err
+}
+
+// UpdateTemplate saves a tenant's template for a kind of document
+func (s *Service) UpdateTemplate(ctx context.Context, template *Template) (*Template, error) {
+	ctx, span := s.tracer.Start(ctx, "UpdateTemplate")
+	defer span.End()
+
+	if err := checkKind(template.Kind); err != nil {
+		return nil, err
+	}
+	if template.AccentColor != "" {
+		if _, ok := parseColor(template.AccentColor); !ok {
+			return nil, fmt.Errorf("%w: accent_color must be a #RRGGBB color", ErrInvalidTemplate)
+		}
+	}
+	if template.Language != "" && !SupportsLanguage(template.Language) {
+		return nil, fmt.Errorf("%w: language must be one of %v", ErrInvalidTemplate, Languages())
+	}
+	for _, field := range []struct{ name, value string }{
+		{"business_name", template.BusinessName},
+		{"address", template.Address},
+		{"tax_id", template.TaxID},
+		{"footer", template.Footer},
+	} {
+		if len(field.value) > 500 {
+			return nil, fmt.Errorf("%w: %s must be at most 500 characters", ErrInvalidTemplate, field.name)
+		}
+	}
+
+	return s.store.UpsertDocumentTemplate(ctx, template)
+}
+
+// DeleteTemplate removes a tenant's template for a kind of document, so its
+// documents are no longer branded. It reports whether there was one.
+func (s *Service) DeleteTemplate(ctx context.Context, tenantID, kind string) (bool, error) {
+	ctx, span := s.tracer.Start(ctx, "DeleteTemplate")
+	defer span.End()
+
+	if err := checkKind(kind); err != nil {
+		return false, err
+	}
+	return s.store.DeleteDocumentTemplate(ctx, tenantID, kind)
+}
+
+// Publish renders a document with the tenant's template and stores it,
+// returning it with a signed download URL. The language defaults to the
+// template's. A document that renders as it did before is not uploaded
+// again.
+func (s *Service) Publish(ctx context.Context, tenantID, language string, content *Content) (*Document, error) {
+	ctx, span := s.tracer.Start(ctx, "Publish")
+	defer span.End()
+	span.SetAttributes(attribute.String("kind", content.Kind), attribute.String("source_id", content.SourceID))
+
+	if !s.Enabled() {
+		return nil, ErrStorageUnavailable
+	}
+
+	template, err := s.Template(ctx, tenantID, content.Kind)
+	if err != nil {
+		return nil, err
+	}
+	if language == "" {
+		language = template.Language
+	}
+	if language == "" {
+		language = DefaultLanguage
+	}
+
+	pdf, err := Render(content, template, language)
+	if err != nil {
+		return nil, err
+	}
+	checksum := sha256Hex(pdf)
+
+	existing, err := s.store.GetDocumentBySource(ctx, tenantID, content.Kind, content.SourceID, language)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if existing != nil && existing.Checksum == checksum {
+		return s.sign(existing)
+	}
+
+	document := &Document{
+		ID:       "doc_" + uuid.New().String(),
+		TenantID: tenantID,
+		Kind:     content.Kind,
+		SourceID: content.SourceID,
+		Language: language,
+		Checksum: checksum,
+		Size:     int64(len(pdf)),
+	}
+	if existing != nil {
+		document.ID = existing.ID
+	}
+	// Keys are addressed by content, so a URL signed for an earlier
+	// rendering never serves a later one
+	document.ObjectKey = fmt.Sprintf("%s%s/%ss/%s/%s-%s.pdf",
+		s.config.Prefix, tenantID, content.Kind, content.SourceID, language, checksum[:12])
+
+	if err := s.objects.Put(ctx, document.ObjectKey, "application/pdf", pdf); err != nil {
+		return nil, err
+	}
+	if document, err = s.store.UpsertDocument(ctx, document); err != nil {
+		return nil, err
+	}
+
+	return s.sign(document)
+}
+
+// Get returns a published document with a freshly signed download URL
+func (s *Service) Get(ctx context.Context, tenantID, id string) (*Document, error) {
+	ctx, span := s.tracer.Start(ctx, "Get")
+	defer span.End()
+
+	if !s.Enabled() {
+		return nil, ErrStorageUnavailable
+	}
+
+	document, err := s.store.GetDocument(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.sign(document)
+}
+
+// sign sets a document's download URL
+func (s *Service) sign(document *Document) (*Document, error) {
+	url, err := s.objects.SignedURL(document.ObjectKey, s.config.URLTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign document URL: %w", err)
+	}
+	expiresAt := time.Now().Add(s.config.URLTTL).UTC()
+	document.URL = url
+	document.URLExpiresAt = &expiresAt
+	return document, nil
+}
+
+// checkKind ensures a kind of document exists
+func checkKind(kind string) error {
+	if kind != KindInvoice && kind != KindReceipt {
+		return fmt.Errorf("%w: kind must be %s or %s", ErrInvalidTemplate, KindInvoice, KindReceipt)
+	}
+	return nil
+}
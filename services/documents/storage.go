@@ -0,0 +1,223 @@
+package documents
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ObjectStore keeps rendered documents and hands out links to download them
+type ObjectStore interface {
+	Put(ctx context.Context, key, contentType string, body []byte) error
+	SignedURL(key string, expires time.Duration) (string, error)
+}
+
+// SigV4 constants
+const (
+	sigV4Algorithm  = "AWS4-HMAC-SHA256"
+	sigV4Service    = "s3"
+	sigV4Terminator = "aws4_request"
+	sigV4TimeFormat = "20060102T150405Z"
+	sigV4DateFormat = "20060102"
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+// S3Store stores objects in an S3-compatible bucket, such as AWS S3, MinIO
+// or Cloudflare R2, signing requests with AWS Signature Version 4
+type S3Store struct {
+	config *Config
+	client *http.Client
+}
+
+// NewS3Store creates a store for the configured bucket
+func NewS3Store(config *Config) *S3Store {
+	return &S3Store{
+		config: config,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Put uploads an object, replacing any object with the same key
+func (s *S3Store) Put(ctx context.Context, key, contentType string, body []byte) error {
+	target, err := objectURL(s.config, key)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create upload request: %w", err)
+	}
+	payloadHash := sha256Hex(body)
+	now := time.Now().UTC()
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	req.Header.Set("X-Amz-Date", now.Format(sigV4TimeFormat))
+
+	headers := map[string]string{
+		"content-type":         contentType,
+		"host":                 target.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           now.Format(sigV4TimeFormat),
+	}
+	signedHeaders, signature := sign(s.config, http.MethodPut, target, headers, payloadHash, now)
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, s.config.AccessKeyID, scope(s.config, now), signedHeaders, signature))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("failed to upload %s: status %d: %s", key, resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// SignedURL returns a presigned URL to download an object until it expires
+func (s *S3Store) SignedURL(key string, expires time.Duration) (string, error) {
+	return Presign(s.config, key, time.Now(), expires)
+}
+
+// Presign returns a URL to download an object, signed at a time and valid
+// for as long as expires
+func Presign(config *Config, key string, at time.Time, expires time.Duration) (string, error) {
+	target, err := objectURL(config, key)
+	if err != nil {
+		return "", err
+	}
+
+	at = at.UTC()
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", sigV4Algorithm)
+	query.Set("X-Amz-Credential", config.AccessKeyID+"/"+scope(config, at))
+	query.Set("X-Amz-Date", at.Format(sigV4TimeFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expires.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+	target.RawQuery = canonicalQuery(query)
+
+	_, signature := sign(config, http.MethodGet, target, map[string]string{"host": target.Host}, unsignedPayload, at)
+	target.RawQuery += "&X-Amz-Signature=" + signature
+
+	return target.String(), nil
+}
+
+// objectURL addresses an object in the bucket, in the path or as a
+// subdomain of the endpoint
+func objectURL(config *Config, key string) (*url.URL, error) {
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid document storage endpoint %q", config.Endpoint)
+	}
+
+	target := &url.URL{Scheme: endpoint.Scheme, Host: endpoint.Host}
+	path := strings.TrimRight(endpoint.Path, "/")
+	if config.PathStyle {
+		path += "/" + config.Bucket
+	} else {
+		target.Host = config.Bucket + "." + endpoint.Host
+	}
+	target.Path = path + "/" + key
+	target.RawPath = awsEscape(target.Path, false)
+
+	return target, nil
+}
+
+// sign computes a request's SigV4 signature from the headers it signs,
+// returning their names as listed in the signature
+func sign(config *Config, method string, target *url.URL, headers map[string]string, payloadHash string, now time.Time) (string, string) {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		target.EscapedPath(),
+		target.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	stringToSign := strings.Join([]string{
+		sigV4Algorithm,
+		now.Format(sigV4TimeFormat),
+		scope(config, now),
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+config.SecretAccessKey), now.Format(sigV4DateFormat))
+	key = hmacSHA256(key, config.Region)
+	key = hmacSHA256(key, sigV4Service)
+	key = hmacSHA256(key, sigV4Terminator)
+
+	return signedHeaders, hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// scope is the date, region and service credentials are scoped to
+func scope(config *Config, now time.Time) string {
+	return strings.Join([]string{now.Format(sigV4DateFormat), config.Region, sigV4Service, sigV4Terminator}, "/")
+}
+
+// canonicalQuery encodes query parameters sorted by name, as SigV4 signs them
+func canonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, awsEscape(name, true)+"="+awsEscape(query.Get(name), true))
+	}
+	return strings.Join(parts, "&")
+}
+
+// awsEscape percent-encodes everything but unreserved characters, and
+// slashes too unless escaping a path
+func awsEscape(value string, encodeSlash bool) string {
+	var b strings.Builder
+	for _, c := range []byte(value) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,173 @@
+package drain
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"apis/payments/services/config"
+)
+
+// Kinds of in-flight work tracked during shutdown
+const (
+	KindRequest = "request"
+	KindWebhook = "webhook"
+	KindJob     = "job"
+)
+
+// Config controls how the service drains on shutdown
+type Config struct {
+	// PreStopDelay is how long the service keeps serving after readiness
+	// flips, giving load balancers time to stop routing to it
+	PreStopDelay time.Duration
+	// GracePeriod bounds how long in-flight work may take to finish once
+	// listeners close
+	GracePeriod time.Duration
+}
+
+// LoadConfig loads drain settings from environment variables
+func LoadConfig() *Config {
+	return &Config{
+		PreStopDelay: time.Duration(config.Int("SHUTDOWN_PRESTOP_DELAY_SECONDS", 5)) * time.Second,
+		GracePeriod:  time.Duration(config.Int("SHUTDOWN_GRACE_PERIOD_SECONDS", 30)) * time.Second,
+	}
+}
+
+// Hook releases a component during shutdown, e.g. a consumer committing its
+// offsets and leaving its group
+type Hook struct {
+	Name  string
+	Close func(ctx context.Context) error
+}
+
+// Tracker counts in-flight work so shutdown can wait for it, and reports
+// readiness so the service is taken out of rotation before it stops
+type Tracker struct {
+	mu       sync.Mutex
+	draining bool
+	inFlight map[string]int
+	idle     chan struct{}
+	hooks    []Hook
+}
+
+// NewTracker creates a tracker that is ready and has no work in flight
+func NewTracker() *Tracker {
+	return &Tracker{inFlight: make(map[string]int)}
+}
+
+// Begin registers a unit of work of the given kind. Work is still accepted
+// while draining, since load balancers may route requests until they see
+// readiness flip. The returned func must be called when the work finishes.
+func (t *Tracker) Begin(kind string) (done func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.inFlight[kind]++
+
+	var once sync.Once
+	return func() { once.Do(func() { t.finish(kind) }) }
+}
+
+// finish removes a unit of work, waking Wait when nothing is left
+func (t *Tracker) finish(kind string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.inFlight[kind]--
+	if t.inFlight[kind] == 0 {
+		delete(t.inFlight, kind)
+	}
+	if len(t.inFlight) == 0 && t.idle != nil {
+		close(t.idle)
+		t.idle = nil
+	}
+}
+
+// OnShutdown registers a hook run by Shutdown. Hooks run in the order they
+// were registered, after in-flight work has finished.
+func (t *Tracker) OnShutdown(name string, fn func(ctx context.Context) error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.hooks = append(t.hooks, Hook{Name: name, Close: fn})
+}
+
+// StartDraining flips readiness so the service is taken out of rotation
+func (t *Tracker) StartDraining() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.draining = true
+}
+
+// Ready reports whether the service should receive traffic
+func (t *Tracker) Ready() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return !t.draining
+}
+
+// InFlight returns the number of units of work in flight by kind
+func (t *Tracker) InFlight() map[string]int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	counts := make(map[string]int, len(t.inFlight))
+	for kind, count := range t.inFlight {
+		counts[kind] = count
+	}
+	return counts
+}
+
+// Wait blocks until no work is in flight or the context ends, returning an
+// error naming the work abandoned in the latter case
+func (t *Tracker) Wait(ctx context.Context) error {
+	t.mu.Lock()
+	if len(t.inFlight) == 0 {
+		t.mu.Unlock()
+		return nil
+	}
+	if t.idle == nil {
+		t.idle = make(chan struct{})
+	}
+	idle := t.idle
+	t.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("abandoned in-flight work (%s): %w", formatCounts(t.InFlight()), ctx.Err())
+	}
+}
+
+// Shutdown runs every registered hook, logging failures, and returns the
+// first error
+func (t *Tracker) Shutdown(ctx context.Context) error {
+	t.mu.Lock()
+	hooks := append([]Hook(nil), t.hooks...)
+	t.mu.Unlock()
+
+	var firstErr error
+	for _, hook := range hooks {
+		if err := hook.Close(ctx); err != nil {
+			log.Printf("Shutdown hook %s failed: %v", hook.Name, err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("shutdown hook %s: %w", hook.Name, err)
+			}
+		}
+	}
+	return firstErr
+}
+
+// formatCounts renders in-flight counts as "job=1, request=2"
+func formatCounts(counts map[string]int) string {
+	parts := make([]string, 0, len(counts))
+	for kind, count := range counts {
+		parts = append(parts, fmt.Sprintf("%s=%d", kind, count))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,217 @@
+package dryrun
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"apis/payments/services"
+	"apis/payments/services/routing"
+)
+
+// Operations that can be dry-run
+const (
+	OperationCharge       = "charge"
+	OperationRefund       = "refund"
+	OperationSubscription = "subscription"
+)
+
+// Outcomes of a dry-run operation
+const (
+	OutcomeCreated         = "created"          // The provider would be called
+	OutcomePendingApproval = "pending_approval" // The operation would wait for approval
+	OutcomeRejected        = "rejected"         // A check would fail the request
+)
+
+var (
+	// ErrUnsupportedOperation is returned when a provider cannot perform an operation
+	ErrUnsupportedOperation = errors.New("provider does not support the operation")
+
+	// ErrAmountOutOfRange is returned for amounts outside a provider's limits
+	ErrAmountOutOfRange = errors.New("amount is outside the provider's limits")
+)
+
+// Check is the outcome of one check an operation runs before calling the provider
+type Check struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Rule is a rule that matched the operation and changed how it is handled
+type Rule struct {
+	Name   string `json:"name"`
+	Detail string `json:"detail"`
+}
+
+// Fee is an estimate of the provider's processing fee
+type Fee struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+	FeeRate
+}
+
+// Result describes what an operation would do
+type Result struct {
+	DryRun       bool              `json:"dry_run"`
+	Operation    string            `json:"operation"`
+	Outcome      string            `json:"outcome"`
+	Provider     string            `json:"provider,omitempty"`
+	Routing      *routing.Decision `json:"routing,omitempty"`
+	Amount       int64             `json:"amount,omitempty"`
+	Currency     string            `json:"currency,omitempty"`
+	EstimatedFee *Fee              `json:"estimated_fee,omitempty"`
+	Checks       []Check           `json:"checks"`
+	RulesMatched []Rule            `json:"rules_matched"`
+}
+
+// New starts the result of a dry run of an operation
+func New(operation string) *Result {
+	return &Result{
+		DryRun:       true,
+		Operation:    operation,
+		Outcome:      OutcomeCreated,
+		Checks:       []Check{},
+		RulesMatched: []Rule{},
+	}
+}
+
+// Check records a check's outcome, reporting whether it passed. A failed
+// check rejects the operation.
+func (r *Result) Check(name string, err error) bool {
+	if err != nil {
+		r.Checks = append(r.Checks, Check{Name: name, Error: err.Error()})
+		r.Outcome = OutcomeRejected
+		return false
+	}
+
+	r.Checks = append(r.Checks, Check{Name: name, Passed: true})
+	return true
+}
+
+// Match records a rule that matched the operation
+func (r *Result) Match(name, detail string) {
+	r.RulesMatched = append(r.RulesMatched, Rule{Name: name, Detail: detail})
+}
+
+// RequireApproval holds the operation for approval unless it is rejected
+func (r *Result) RequireApproval() {
+	if r.Outcome != OutcomeRejected {
+		r.Outcome = OutcomePendingApproval
+	}
+}
+
+// Route records the routing decision and the provider it chose
+func (r *Result) Route(decision *routing.Decision) {
+	r.Provider = decision.Provider
+	r.Routing = decision
+	if decision.Reason == routing.ReasonCurrency {
+		r.Match("currency_route", fmt.Sprintf("%s routes to %s", decision.Currency, decision.Provider))
+	}
+}
+
+// CheckCapabilities returns an error if the provider's advertised
+// capabilities rule out the operation. Amounts are only checked when positive.
+func CheckCapabilities(provider, operation string, amount int64) error {
+	capabilities, err := services.Capabilities(provider)
+	if err != nil {
+		return err
+	}
+
+	supported := map[string]bool{
+		OperationCharge:       capabilities.SupportsCharges,
+		OperationRefund:       capabilities.SupportsRefunds,
+		OperationSubscription: capabilities.SupportsSubscriptions,
+	}
+	if !supported[operation] {
+		return fmt.Errorf("%w: %s cannot create a %s", ErrUnsupportedOperation, provider, operation)
+	}
+
+	if amount > 0 && (amount < capabilities.MinChargeAmount || amount > capabilities.MaxChargeAmount) {
+		return fmt.Errorf("%w: %s accepts %d to %d", ErrAmountOutOfRange, provider, capabilities.MinChargeAmount, capabilities.MaxChargeAmount)
+	}
+
+	return nil
+}
+
+// FeeRate is a provider's processing fee: a percentage of the amount plus a
+// fixed amount in the currency's minor units
+type FeeRate struct {
+	Percent float64 `json:"percent"`
+	Fixed   int64   `json:"fixed"`
+}
+
+// Config holds the fee rates used to estimate provider fees
+type Config struct {
+	Fees map[string]FeeRate
+}
+
+// LoadConfig loads fee rates from PROVIDER_FEES, comma-separated
+// provider=percent%+fixed pairs, e.g. stripe=2.9%+30,paypal=3.49%+49.
+// Stripe's standard card rate applies unless it is overridden.
+func LoadConfig() *Config {
+	config := &Config{
+		Fees: map[string]FeeRate{"stripe": {Percent: 2.9, Fixed: 30}},
+	}
+
+	for _, part := range strings.Split(os.Getenv("PROVIDER_FEES"), ",") {
+		provider, value, ok := strings.Cut(part, "=")
+		if !ok {
+			continue
+		}
+		rate, err := ParseFeeRate(value)
+		if err != nil {
+			continue
+		}
+		config.Fees[strings.ToLower(strings.TrimSpace(provider))] = rate
+	}
+
+	return config
+}
+
+// ParseFeeRate parses a fee rate such as 2.9%+30, 1.4% or 25
+func ParseFeeRate(value string) (FeeRate, error) {
+	var rate FeeRate
+	value = strings.ReplaceAll(value, " ", "")
+
+	percent, fixed, hasPercent := strings.Cut(value, "%")
+	if !hasPercent {
+		percent, fixed = "", value
+	}
+	if percent != "" {
+		parsed, err := strconv.ParseFloat(percent, 64)
+		if err != nil || parsed < 0 {
+			return rate, fmt.Errorf("invalid fee percentage %q", percent)
+		}
+		rate.Percent = parsed
+	}
+
+	fixed = strings.TrimPrefix(fixed, "+")
+	if fixed != "" {
+		parsed, err := strconv.ParseInt(fixed, 10, 64)
+		if err != nil || parsed < 0 {
+			return rate, fmt.Errorf("invalid fixed fee %q", fixed)
+		}
+		rate.Fixed = parsed
+	}
+
+	return rate, nil
+}
+
+// EstimateFee estimates the provider's fee for an amount, or returns nil
+// when no rate is configured for the provider
+func (c *Config) EstimateFee(provider, currency string, amount int64) *Fee {
+	rate, ok := c.Fees[provider]
+	if !ok || amount <= 0 {
+		return nil
+	}
+
+	return &Fee{
+		Amount:   int64(math.Round(float64(amount)*rate.Percent/100)) + rate.Fixed,
+		Currency: currency,
+		FeeRate:  rate,
+	}
+}
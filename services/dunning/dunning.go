@@ -0,0 +1,179 @@
+package dunning
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"apis/payments/services/config"
+	"apis/payments/services/stripe"
+)
+
+// Dunning states. A case moves forward from grace as time passes since the
+// first failure and ends recovered, canceled or closed.
+const (
+	StateGrace     = "grace"     // Retrying; the customer keeps full service
+	StatePastDue   = "past_due"  // Still retrying after the grace period
+	StateSuspended = "suspended" // Subscription collection paused
+	StateCanceled  = "canceled"  // Subscription canceled
+	StateRecovered = "recovered" // Invoice paid
+	StateClosed    = "closed"    // Invoice voided or written off
+)
+
+// Event types emitted as dunning cases progress
+const (
+	EventStarted     = "payments.dunning.started"
+	EventRetryFailed = "payments.dunning.retry_failed"
+	EventPastDue     = "payments.dunning.past_due"
+	EventSuspended   = "payments.dunning.suspended"
+	EventCanceled    = "payments.dunning.canceled"
+	EventRecovered   = "payments.dunning.recovered"
+)
+
+// stateEvents are the events emitted on entering a state
+var stateEvents = map[string]string{
+	StatePastDue:   EventPastDue,
+	StateSuspended: EventSuspended,
+	StateCanceled:  EventCanceled,
+	StateRecovered: EventRecovered,
+}
+
+// ErrCaseNotFound is returned for unknown dunning cases
+var ErrCaseNotFound = errors.New("dunning case not found")
+
+// ErrCaseResolved is returned when retrying a case that is no longer open
+var ErrCaseResolved = errors.New("dunning case is already resolved")
+
+// Case tracks collection of one failed subscription invoice
+type Case struct {
+	ID             string     `json:"id"`
+	InvoiceID      string     `json:"invoice_id"`
+	SubscriptionID string     `json:"subscription_id"`
+	CustomerID     string     `json:"customer_id"`
+	AmountDue      int64      `json:"amount_due"`
+	Currency       string     `json:"currency"`
+	State          string     `json:"state"`
+	Attempts       int        `json:"attempts"` // Retries made by the engine
+	NextRetryAt    *time.Time `json:"next_retry_at,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	FailedAt       time.Time  `json:"failed_at"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// Open reports whether the case is still being collected
+func (c *Case) Open() bool {
+	return c.ResolvedAt == nil
+}
+
+// Filter narrows a dunning case listing
+type Filter struct {
+	State          string
+	CustomerID     string
+	SubscriptionID string
+	Limit          int
+}
+
+// Config controls the retry schedule and how long each state lasts. Days
+// are counted from the first failed payment.
+type Config struct {
+	Enabled     bool
+	RetryDays   []int // Days on which payment is retried
+	GraceDays   int   // After which the case is past_due
+	SuspendDays int   // After which the subscription is paused; 0 never suspends
+	CancelDays  int   // After which the subscription is canceled; 0 never cancels
+	Interval    time.Duration
+	BatchSize   int
+}
+
+// LoadConfig loads the dunning configuration from environment variables
+func LoadConfig() *Config {
+	config := &Config{
+		RetryDays:   []int{1, 3, 7},
+		GraceDays:   config.Int("DUNNING_GRACE_DAYS", 3),
+		SuspendDays: config.Int("DUNNING_SUSPEND_DAYS", 14),
+		CancelDays:  config.Int("DUNNING_CANCEL_DAYS", 30),
+		Interval:    time.Duration(config.Int("DUNNING_INTERVAL_MINUTES", 60)) * time.Minute,
+		BatchSize:   config.Int("DUNNING_BATCH_SIZE", 500),
+	}
+
+	if enabled, err := strconv.ParseBool(os.Getenv("DUNNING_ENABLED")); err == nil {
+		config.Enabled = enabled
+	}
+	if value := os.Getenv("DUNNING_RETRY_DAYS"); value != "" {
+		var days []int
+		for _, part := range strings.Split(value, ",") {
+			day, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || day <= 0 {
+				continue
+			}
+			days = append(days, day)
+		}
+		config.RetryDays = days
+	}
+
+	sort.Ints(config.RetryDays)
+	return config
+}
+
+// nextRetry returns when the first scheduled retry after after is due, or
+// nil once the schedule is used up
+func (c *Config) nextRetry(failedAt, after time.Time) *time.Time {
+	for _, day := range c.RetryDays {
+		next := failedAt.Add(time.Duration(day) * 24 * time.Hour)
+		if next.After(after) {
+			return &next
+		}
+	}
+	return nil
+}
+
+// stateAt returns the state a case that failed at failedAt is in at now
+func (c *Config) stateAt(failedAt, now time.Time) string {
+	days := int(now.Sub(failedAt) / (24 * time.Hour))
+	switch {
+	case c.CancelDays > 0 && days >= c.CancelDays:
+		return StateCanceled
+	case c.SuspendDays > 0 && days >= c.SuspendDays:
+		return StateSuspended
+	case days >= c.GraceDays:
+		return StatePastDue
+	default:
+		return StateGrace
+	}
+}
+
+// stateOrder ranks open states so cases only move forward
+var stateOrder = map[string]int{
+	StateGrace:     0,
+	StatePastDue:   1,
+	StateSuspended: 2,
+	StateCanceled:  3,
+}
+
+// Store persists dunning cases. Updates of resolved cases return sql.ErrNoRows.
+type Store interface {
+	OpenDunningCase(ctx context.Context, dunningCase *Case) (bool, error)
+	GetDunningCase(ctx context.Context, id string) (*Case, error)
+	GetDunningCaseByInvoice(ctx context.Context, invoiceID string) (*Case, error)
+	ListDunningCases(ctx context.Context, filter Filter) ([]*Case, error)
+	ListOpenDunningCases(ctx context.Context, limit int) ([]*Case, error)
+	UpdateDunningCase(ctx context.Context, dunningCase *Case) (*Case, error)
+}
+
+// Invoices retries payment of invoices at the provider
+type Invoices interface {
+	PayInvoice(ctx context.Context, invoiceID, paymentMethodID string) (*stripe.Invoice, error)
+}
+
+// Subscriptions suspends, restores and cancels subscriptions at the provider
+type Subscriptions interface {
+	PauseSubscription(ctx context.Context, subscriptionID string) (*stripe.Subscription, error)
+	UnpauseSubscription(ctx context.Context, subscriptionID string) (*stripe.Subscription, error)
+	CancelSubscription(ctx context.Context, subscriptionID string, atPeriodEnd bool) (*stripe.Subscription, error)
+}
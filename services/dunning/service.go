@@ -0,0 +1,355 @@
+package dunning
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"apis/payments/services/events"
+	"apis/payments/services/jobs"
+	"apis/payments/services/stripe"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Service opens a dunning case for each failed subscription invoice, retries
+// payment on schedule and suspends and cancels subscriptions that stay unpaid
+type Service struct {
+	store         Store
+	invoices      Invoices
+	subscriptions Subscriptions
+	emitter       *events.Emitter
+	config        *Config
+	tracer        trace.Tracer
+}
+
+// NewService creates a new dunning service
+func NewService(store Store, invoices Invoices, subscriptions Subscriptions, emitter *events.Emitter, config *Config) *Service {
+	if config == nil {
+		config = LoadConfig()
+	}
+
+	return &Service{
+		store:         store,
+		invoices:      invoices,
+		subscriptions: subscriptions,
+		emitter:       emitter,
+		config:        config,
+		tracer:        otel.Tracer("payments.dunning"),
+	}
+}
+
+// PaymentFailed opens a case in grace for an open subscription invoice that
+// is charged automatically and failed to pay. Later failures of the same
+// invoice, including those of retries, leave the case as it is.
+func (s *Service) PaymentFailed(ctx context.Context, inv *stripe.Invoice, failedAt time.Time) (*Case, error) {
+	ctx, span := s.tracer.Start(ctx, "PaymentFailed")
+	defer span.End()
+
+	if !s.config.Enabled || inv.SubscriptionID == "" || inv.CollectionMethod != "charge_automatically" || inv.Status != "open" {
+		return nil, nil
+	}
+
+	dunningCase := &Case{
+		ID:             fmt.Sprintf("dun_%s", uuid.New().String()),
+		InvoiceID:      inv.ID,
+		SubscriptionID: inv.SubscriptionID,
+		CustomerID:     inv.CustomerID,
+		AmountDue:      inv.AmountRemaining,
+		Currency:       inv.Currency,
+		State:          StateGrace,
+		NextRetryAt:    s.config.nextRetry(failedAt, failedAt),
+		FailedAt:       failedAt,
+	}
+	opened, err := s.store.OpenDunningCase(ctx, dunningCase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dunning case: %w", err)
+	}
+	if !opened {
+		return s.store.GetDunningCaseByInvoice(ctx, inv.ID)
+	}
+
+	stored, err := s.store.GetDunningCaseByInvoice(ctx, inv.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dunning case: %w", err)
+	}
+	s.emit(ctx, EventStarted, stored)
+	return stored, nil
+}
+
+// InvoicePaid recovers the open case of an invoice that was paid, whether by
+// a retry, the customer or the provider's own retries
+func (s *Service) InvoicePaid(ctx context.Context, invoiceID string, paidAt time.Time) error {
+	ctx, span := s.tracer.Start(ctx, "InvoicePaid")
+	defer span.End()
+
+	dunningCase, err := s.openCase(ctx, invoiceID)
+	if err != nil || dunningCase == nil {
+		return err
+	}
+
+	return s.resolve(ctx, dunningCase, StateRecovered, paidAt)
+}
+
+// InvoiceClosed closes the open case of an invoice that was voided or
+// marked uncollectible, leaving the subscription as it is
+func (s *Service) InvoiceClosed(ctx context.Context, invoiceID string, closedAt time.Time) error {
+	ctx, span := s.tracer.Start(ctx, "InvoiceClosed")
+	defer span.End()
+
+	dunningCase, err := s.openCase(ctx, invoiceID)
+	if err != nil || dunningCase == nil {
+		return err
+	}
+
+	return s.resolve(ctx, dunningCase, StateClosed, closedAt)
+}
+
+// Advance retries the payments of open cases that are due and moves cases
+// into the state their age calls for. It returns how many cases changed.
+func (s *Service) Advance(ctx context.Context, now time.Time) (int, error) {
+	ctx, span := s.tracer.Start(ctx, "Advance")
+	defer span.End()
+
+	if !s.config.Enabled {
+		return 0, nil
+	}
+
+	cases, err := s.store.ListOpenDunningCases(ctx, s.config.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list open dunning cases: %w", err)
+	}
+
+	changed := 0
+	for _, dunningCase := range cases {
+		advanced, err := s.advance(ctx, dunningCase, now)
+		if err != nil {
+			log.Printf("Failed to advance dunning case %s: %v", dunningCase.ID, err)
+			continue
+		}
+		if advanced {
+			changed++
+		}
+	}
+
+	return changed, nil
+}
+
+// Retry retries payment of an open case now. The retry schedule is unchanged.
+func (s *Service) Retry(ctx context.Context, id string) (*Case, error) {
+	ctx, span := s.tracer.Start(ctx, "Retry")
+	defer span.End()
+
+	dunningCase, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !dunningCase.Open() {
+		return nil, ErrCaseResolved
+	}
+
+	if _, err := s.retry(ctx, dunningCase, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return s.Get(ctx, id)
+}
+
+// RetryCustomer retries payment of a customer's open cases now, e.g. once
+// their card was updated, returning how many were retried. The retry
+// schedules are unchanged.
+func (s *Service) RetryCustomer(ctx context.Context, customerID string) (int, error) {
+	ctx, span := s.tracer.Start(ctx, "RetryCustomer")
+	defer span.End()
+
+	if !s.config.Enabled {
+		return 0, nil
+	}
+
+	cases, err := s.store.ListDunningCases(ctx, Filter{CustomerID: customerID, Limit: s.config.BatchSize})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list dunning cases: %w", err)
+	}
+
+	retried := 0
+	for _, dunningCase := range cases {
+		if !dunningCase.Open() {
+			continue
+		}
+		if _, err := s.retry(ctx, dunningCase, time.Now()); err != nil {
+			log.Printf("Failed to retry dunning case %s: %v", dunningCase.ID, err)
+			continue
+		}
+		retried++
+	}
+
+	return retried, nil
+}
+
+// Get retrieves a dunning case
+func (s *Service) Get(ctx context.Context, id string) (*Case, error) {
+	dunningCase, err := s.store.GetDunningCase(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCaseNotFound
+	}
+	return dunningCase, err
+}
+
+// List lists dunning cases, newest first
+func (s *Service) List(ctx context.Context, filter Filter) ([]*Case, error) {
+	if filter.Limit <= 0 || filter.Limit > 500 {
+		filter.Limit = 100
+	}
+	return s.store.ListDunningCases(ctx, filter)
+}
+
+// Job advances dunning cases every configured interval. It returns nil
+// unless dunning is enabled.
+func (s *Service) Job() *jobs.Job {
+	if !s.config.Enabled {
+		return nil
+	}
+
+	return &jobs.Job{
+		Name:     "dunning",
+		Schedule: jobs.Every(s.config.Interval),
+		Run: func(ctx context.Context) error {
+			_, err := s.Advance(ctx, time.Now())
+			return err
+		},
+	}
+}
+
+// advance retries a case's payment when due and moves it forward
+func (s *Service) advance(ctx context.Context, dunningCase *Case, now time.Time) (bool, error) {
+	changed := false
+	if dunningCase.NextRetryAt != nil && !now.Before(*dunningCase.NextRetryAt) {
+		recovered, err := s.retry(ctx, dunningCase, now)
+		if err != nil {
+			return false, err
+		}
+		if recovered {
+			return true, nil
+		}
+		changed = true
+	}
+
+	state := s.config.stateAt(dunningCase.FailedAt, now)
+	if stateOrder[state] <= stateOrder[dunningCase.State] {
+		return changed, nil
+	}
+
+	return true, s.transition(ctx, dunningCase, state, now)
+}
+
+// retry attempts payment of a case's invoice, recovering the case when it
+// succeeds and scheduling the next retry when it fails
+func (s *Service) retry(ctx context.Context, dunningCase *Case, now time.Time) (bool, error) {
+	dunningCase.Attempts++
+	_, payErr := s.invoices.PayInvoice(ctx, dunningCase.InvoiceID, "")
+	if payErr == nil {
+		return true, s.resolve(ctx, dunningCase, StateRecovered, now)
+	}
+
+	dunningCase.LastError = payErr.Error()
+	dunningCase.NextRetryAt = s.config.nextRetry(dunningCase.FailedAt, now)
+	updated, err := s.update(ctx, dunningCase)
+	if err != nil || updated == nil {
+		return false, err
+	}
+
+	s.emit(ctx, EventRetryFailed, updated)
+	return false, nil
+}
+
+// transition moves an open case into a later state, suspending or
+// canceling its subscription first
+func (s *Service) transition(ctx context.Context, dunningCase *Case, state string, now time.Time) error {
+	switch state {
+	case StateSuspended:
+		if _, err := s.subscriptions.PauseSubscription(ctx, dunningCase.SubscriptionID); err != nil {
+			return fmt.Errorf("failed to suspend subscription: %w", err)
+		}
+	case StateCanceled:
+		if _, err := s.subscriptions.CancelSubscription(ctx, dunningCase.SubscriptionID, false); err != nil {
+			return fmt.Errorf("failed to cancel subscription: %w", err)
+		}
+		dunningCase.NextRetryAt = nil
+		dunningCase.ResolvedAt = &now
+	}
+
+	dunningCase.State = state
+	updated, err := s.update(ctx, dunningCase)
+	if err != nil || updated == nil {
+		return err
+	}
+
+	s.emit(ctx, stateEvents[state], updated)
+	return nil
+}
+
+// resolve ends an open case, restoring a suspended subscription when its
+// invoice was paid
+func (s *Service) resolve(ctx context.Context, dunningCase *Case, state string, now time.Time) error {
+	if state == StateRecovered && dunningCase.State == StateSuspended {
+		if _, err := s.subscriptions.UnpauseSubscription(ctx, dunningCase.SubscriptionID); err != nil {
+			return fmt.Errorf("failed to restore subscription: %w", err)
+		}
+	}
+
+	dunningCase.State = state
+	dunningCase.NextRetryAt = nil
+	dunningCase.ResolvedAt = &now
+	updated, err := s.update(ctx, dunningCase)
+	if err != nil || updated == nil {
+		return err
+	}
+
+	if eventType, ok := stateEvents[state]; ok {
+		s.emit(ctx, eventType, updated)
+	}
+	return nil
+}
+
+// update stores a case, returning nil when it was resolved meanwhile
+func (s *Service) update(ctx context.Context, dunningCase *Case) (*Case, error) {
+	updated, err := s.store.UpdateDunningCase(ctx, dunningCase)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update dunning case: %w", err)
+	}
+	return updated, nil
+}
+
+// openCase returns the open case of an invoice, or nil if it has none
+func (s *Service) openCase(ctx context.Context, invoiceID string) (*Case, error) {
+	dunningCase, err := s.store.GetDunningCaseByInvoice(ctx, invoiceID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dunning case: %w", err)
+	}
+	if !dunningCase.Open() {
+		return nil, nil
+	}
+	return dunningCase, nil
+}
+
+// emit publishes a dunning event with the subscription as subject.
+// Publishing failures are logged.
+func (s *Service) emit(ctx context.Context, eventType string, dunningCase *Case) {
+	if s.emitter == nil {
+		return
+	}
+	if err := s.emitter.Emit(ctx, "dunning", eventType, dunningCase.SubscriptionID, dunningCase); err != nil {
+		log.Printf("Failed to emit %s for %s: %v", eventType, dunningCase.ID, err)
+	}
+}
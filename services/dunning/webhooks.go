@@ -0,0 +1,46 @@
+package dunning
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"apis/payments/services/stripe"
+
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// RegisterWebhookHandlers opens dunning cases for failed invoice payments and
+// resolves them when the invoice is paid, voided or written off
+func (s *Service) RegisterWebhookHandlers(webhooks *stripe.WebhookService) {
+	webhooks.On(stripego.EventTypeInvoicePaymentFailed, func(ctx context.Context, event stripego.Event) error {
+		var stripeInvoice stripego.Invoice
+		if err := json.Unmarshal(event.Data.Raw, &stripeInvoice); err != nil {
+			return fmt.Errorf("failed to parse invoice: %w", err)
+		}
+
+		_, err := s.PaymentFailed(ctx, stripe.ConvertInvoice(&stripeInvoice), time.Unix(event.Created, 0))
+		return err
+	})
+
+	webhooks.On(stripego.EventTypeInvoicePaid, func(ctx context.Context, event stripego.Event) error {
+		var stripeInvoice stripego.Invoice
+		if err := json.Unmarshal(event.Data.Raw, &stripeInvoice); err != nil {
+			return fmt.Errorf("failed to parse invoice: %w", err)
+		}
+
+		return s.InvoicePaid(ctx, stripeInvoice.ID, time.Unix(event.Created, 0))
+	})
+
+	for _, eventType := range []stripego.EventType{stripego.EventTypeInvoiceVoided, stripego.EventTypeInvoiceMarkedUncollectible} {
+		webhooks.On(eventType, func(ctx context.Context, event stripego.Event) error {
+			var stripeInvoice stripego.Invoice
+			if err := json.Unmarshal(event.Data.Raw, &stripeInvoice); err != nil {
+				return fmt.Errorf("failed to parse invoice: %w", err)
+			}
+
+			return s.InvoiceClosed(ctx, stripeInvoice.ID, time.Unix(event.Created, 0))
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package egress
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default timeouts applied to every gateway HTTP call
+const (
+	DefaultDialTimeout           = 10 * time.Second
+	DefaultTLSHandshakeTimeout   = 10 * time.Second
+	DefaultResponseHeaderTimeout = 60 * time.Second
+	DefaultRequestTimeout        = 80 * time.Second
+)
+
+// Config describes how a provider's outbound HTTP calls leave the network
+type Config struct {
+	Provider              string
+	ProxyURL              *url.URL
+	ClientCertFile        string
+	ClientKeyFile         string
+	RootCAFile            string
+	DialTimeout           time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	RequestTimeout        time.Duration
+}
+
+// LoadConfig reads the egress configuration for a provider from the
+// environment. Each setting is looked up as <PROVIDER>_<SETTING> first and
+// GATEWAY_<SETTING> second, so a shared proxy can be overridden per provider.
+func LoadConfig(provider string) (*Config, error) {
+	config := &Config{
+		Provider:       provider,
+		ClientCertFile: lookup(provider, "CLIENT_CERT_FILE"),
+		ClientKeyFile:  lookup(provider, "CLIENT_KEY_FILE"),
+		RootCAFile:     lookup(provider, "ROOT_CA_FILE"),
+	}
+
+	if value := lookup(provider, "EGRESS_PROXY_URL"); value != "" {
+		proxyURL, err := url.Parse(value)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid egress proxy URL for %s: %q", provider, value)
+		}
+		config.ProxyURL = proxyURL
+	}
+
+	if (config.ClientCertFile == "") != (config.ClientKeyFile == "") {
+		return nil, fmt.Errorf("client certificate and key must both be set for %s", provider)
+	}
+
+	timeouts := []struct {
+		setting  string
+		target   *time.Duration
+		fallback time.Duration
+	}{
+		{"DIAL_TIMEOUT_MS", &config.DialTimeout, DefaultDialTimeout},
+		{"TLS_HANDSHAKE_TIMEOUT_MS", &config.TLSHandshakeTimeout, DefaultTLSHandshakeTimeout},
+		{"RESPONSE_HEADER_TIMEOUT_MS", &config.ResponseHeaderTimeout, DefaultResponseHeaderTimeout},
+		{"REQUEST_TIMEOUT_MS", &config.RequestTimeout, DefaultRequestTimeout},
+	}
+	for _, timeout := range timeouts {
+		*timeout.target = timeout.fallback
+		value := lookup(provider, timeout.setting)
+		if value == "" {
+			continue
+		}
+		ms, err := strconv.Atoi(value)
+		if err != nil || ms <= 0 {
+			return nil, fmt.Errorf("invalid %s for %s: %q", timeout.setting, provider, value)
+		}
+		*timeout.target = time.Duration(ms) * time.Millisecond
+	}
+
+	return config, nil
+}
+
+// lookup returns the provider-specific value of a setting, falling back to
+// the value shared by all gateways
+func lookup(provider, setting string) string {
+	if value := os.Getenv(strings.ToUpper(provider) + "_" + setting); value != "" {
+		return value
+	}
+	return os.Getenv("GATEWAY_" + setting)
+}
+
+// NewTransport builds an HTTP transport that dials through the configured
+// proxy, presents the client certificate and enforces the configured timeouts.
+// Without an explicit proxy the standard HTTPS_PROXY/NO_PROXY variables apply.
+func NewTransport(config *Config) (*http.Transport, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if config.ClientCertFile != "" {
+		certificate, err := tls.LoadX509KeyPair(config.ClientCertFile, config.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate for %s: %w", config.Provider, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	if config.RootCAFile != "" {
+		pem, err := os.ReadFile(config.RootCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read root CA for %s: %w", config.Provider, err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in root CA for %s", config.Provider)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if config.ProxyURL != nil {
+		proxy = http.ProxyURL(config.ProxyURL)
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   config.DialTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+
+	return &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       tlsConfig,
+		TLSHandshakeTimeout:   config.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: config.ResponseHeaderTimeout,
+		ExpectContinueTimeout: time.Second,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+	}, nil
+}
@@ -0,0 +1,97 @@
+package ephemeralkeys
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Scopes an ephemeral key can be limited to
+const (
+	ScopePaymentMethodsRead  = "payment_methods.read"
+	ScopePaymentMethodsWrite = "payment_methods.write"
+)
+
+// SecretPrefix identifies ephemeral key secrets
+const SecretPrefix = "ek_"
+
+var (
+	// ErrInvalidKey is returned for unknown, expired or revoked keys
+	ErrInvalidKey = errors.New("invalid or expired ephemeral key")
+	// ErrScopeNotAllowed is returned when a key lacks the scope a call needs
+	ErrScopeNotAllowed = errors.New("ephemeral key does not allow this action")
+	// ErrUnknownScope is returned when issuing a key with an unsupported scope
+	ErrUnknownScope = errors.New("unknown ephemeral key scope")
+)
+
+// Config bounds how long ephemeral keys live
+type Config struct {
+	DefaultTTL time.Duration
+	MaxTTL     time.Duration
+}
+
+// LoadConfig loads the ephemeral key configuration from environment variables
+func LoadConfig() *Config {
+	config := &Config{
+		DefaultTTL: time.Hour,
+		MaxTTL:     24 * time.Hour,
+	}
+
+	if minutes, err := strconv.Atoi(os.Getenv("EPHEMERAL_KEY_TTL_MINUTES")); err == nil && minutes > 0 {
+		config.DefaultTTL = time.Duration(minutes) * time.Minute
+	}
+	if minutes, err := strconv.Atoi(os.Getenv("EPHEMERAL_KEY_MAX_TTL_MINUTES")); err == nil && minutes > 0 {
+		config.MaxTTL = time.Duration(minutes) * time.Minute
+	}
+	if config.DefaultTTL > config.MaxTTL {
+		config.DefaultTTL = config.MaxTTL
+	}
+
+	return config
+}
+
+// Key is an ephemeral key scoped to one customer. The secret is only
+// returned when the key is issued.
+type Key struct {
+	ID         string     `json:"id"`
+	Secret     string     `json:"secret,omitempty"`
+	CustomerID string     `json:"customer_id"`
+	Scopes     []string   `json:"scopes"`
+	IssuedBy   string     `json:"-"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Allows reports whether the key grants a scope
+func (k *Key) Allows(scope string) bool {
+	for _, granted := range k.Scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Active reports whether the key can still be used at the given time
+func (k *Key) Active(now time.Time) bool {
+	return k.RevokedAt == nil && now.Before(k.ExpiresAt)
+}
+
+// Store persists ephemeral keys by the hash of their secret
+type Store interface {
+	CreateEphemeralKey(ctx context.Context, key *Key, secretHash string) (*Key, error)
+	GetEphemeralKeyBySecretHash(ctx context.Context, secretHash string) (*Key, error)
+	RevokeEphemeralKey(ctx context.Context, id string, revokedAt time.Time) (bool, error)
+}
+
+// IsScope reports whether a scope can be granted
+func IsScope(scope string) bool {
+	switch scope {
+	case ScopePaymentMethodsRead, ScopePaymentMethodsWrite:
+		return true
+	}
+	return false
+}
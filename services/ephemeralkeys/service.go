@@ -0,0 +1,143 @@
+package ephemeralkeys
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Service issues and authenticates ephemeral keys
+type Service struct {
+	store  Store
+	config *Config
+	tracer trace.Tracer
+}
+
+// NewService creates a new ephemeral key service
+func NewService(store Store, config *Config) *Service {
+	return &Service{
+		store:  store,
+		config: config,
+		tracer: otel.Tracer("payments.ephemeralkeys"),
+	}
+}
+
+// IssueRequest asks for an ephemeral key for a customer
+type IssueRequest struct {
+	CustomerID string   `json:"customer_id"`
+	Scopes     []string `json:"scopes,omitempty"`      // Defaults to every payment method scope
+	TTLSeconds int      `json:"ttl_seconds,omitempty"` // Defaults to the configured TTL
+	IssuedBy   string   `json:"-"`
+}
+
+// Issue creates an ephemeral key. The returned key carries the secret, which
+// cannot be retrieved again.
+func (s *Service) Issue(ctx context.Context, request *IssueRequest) (*Key, error) {
+	ctx, span := s.tracer.Start(ctx, "Issue")
+	defer span.End()
+
+	if request.CustomerID == "" {
+		return nil, fmt.Errorf("customer ID cannot be empty")
+	}
+
+	scopes := request.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{ScopePaymentMethodsRead, ScopePaymentMethodsWrite}
+	}
+	for _, scope := range scopes {
+		if !IsScope(scope) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownScope, scope)
+		}
+	}
+
+	ttl := s.config.DefaultTTL
+	if request.TTLSeconds > 0 {
+		ttl = time.Duration(request.TTLSeconds) * time.Second
+	}
+	if ttl > s.config.MaxTTL {
+		ttl = s.config.MaxTTL
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := s.store.CreateEphemeralKey(ctx, &Key{
+		ID:         fmt.Sprintf("ephkey_%s", uuid.New().String()),
+		CustomerID: request.CustomerID,
+		Scopes:     scopes,
+		IssuedBy:   request.IssuedBy,
+		ExpiresAt:  time.Now().Add(ttl),
+	}, HashSecret(secret))
+	if err != nil {
+		return nil, err
+	}
+
+	key.Secret = secret
+	return key, nil
+}
+
+// Authenticate returns the active key for a secret
+func (s *Service) Authenticate(ctx context.Context, secret string) (*Key, error) {
+	ctx, span := s.tracer.Start(ctx, "Authenticate")
+	defer span.End()
+
+	if !strings.HasPrefix(secret, SecretPrefix) {
+		return nil, ErrInvalidKey
+	}
+
+	key, err := s.store.GetEphemeralKeyBySecretHash(ctx, HashSecret(secret))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !key.Active(time.Now()) {
+		return nil, ErrInvalidKey
+	}
+
+	return key, nil
+}
+
+// Revoke invalidates a key before it expires
+func (s *Service) Revoke(ctx context.Context, id string) error {
+	ctx, span := s.tracer.Start(ctx, "Revoke")
+	defer span.End()
+
+	revoked, err := s.store.RevokeEphemeralKey(ctx, id, time.Now())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrInvalidKey
+	}
+
+	return nil
+}
+
+// HashSecret hashes a secret for storage and lookup
+func HashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// newSecret generates a random key secret
+func newSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate ephemeral key: %w", err)
+	}
+	return SecretPrefix + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,121 @@
+package eventarchive
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"apis/payments/services/batching"
+	"apis/payments/services/events"
+)
+
+var (
+	// ErrInvalidReplay is returned for replays without a time range or with
+	// no single destination
+	ErrInvalidReplay = errors.New("invalid event replay")
+	// ErrTopicsUnavailable is returned when replaying to a topic while events
+	// are not published to Kafka
+	ErrTopicsUnavailable = errors.New("event topics are not configured")
+)
+
+// Record is an archived event with the tenant it was emitted for
+type Record struct {
+	Event      *events.Event `json:"event"`
+	TenantID   string        `json:"tenant_id"`
+	ArchivedAt time.Time     `json:"archived_at"`
+}
+
+// Filter selects archived events. Events are listed oldest first, from
+// just after the After cursor when it is set.
+type Filter struct {
+	From     time.Time
+	To       time.Time
+	TenantID string
+	Type     string // An exact type, or a prefix such as payments.charge.*
+	Subject  string // The ID of the resource the events are about
+	After    *Cursor
+	Limit    int
+}
+
+// Cursor is the position of an archived event in listing order
+type Cursor struct {
+	Time time.Time
+	ID   string
+}
+
+// ReplayRequest selects archived events and where to publish them again.
+// Exactly one of Topic and EndpointID is set; replays to an endpoint only
+// send the events of the endpoint's tenant that it subscribes to.
+type ReplayRequest struct {
+	From       *time.Time `json:"from"`
+	To         *time.Time `json:"to"` // Now when unset
+	TenantID   string     `json:"tenant_id"`
+	Type       string     `json:"type"`
+	Subject    string     `json:"subject"`
+	Topic      string     `json:"topic"`
+	EndpointID string     `json:"endpoint_id"`
+	DryRun     bool       `json:"dry_run"` // Count and list the events without publishing them
+	Limit      int        `json:"limit"`   // Most events replayed, capped by the configured maximum
+}
+
+// ReplayResult summarizes a replay
+type ReplayResult struct {
+	DryRun    bool           `json:"dry_run"`
+	Matched   int            `json:"matched"`
+	Replayed  int            `json:"replayed"`
+	Skipped   int            `json:"skipped"`   // Events of types the endpoint does not subscribe to
+	Truncated bool           `json:"truncated"` // More events matched than the limit
+	Events    []*Summary     `json:"events,omitempty"`
+	Batch     batching.Stats `json:"batch"` // The batch size the replay ended on
+}
+
+// Summary identifies an event a dry run would replay
+type Summary struct {
+	ID      string    `json:"id"`
+	Type    string    `json:"type"`
+	Subject string    `json:"subject,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// Config controls archiving and replays
+type Config struct {
+	Enabled   bool
+	Retention time.Duration // How long events are kept; forever when 0
+	MaxReplay int           // Most events one replay publishes
+}
+
+// LoadConfig loads the archive configuration from environment variables
+func LoadConfig() *Config {
+	config := &Config{
+		Enabled:   true,
+		Retention: 30 * 24 * time.Hour,
+		MaxReplay: 10000,
+	}
+
+	if enabled, err := strconv.ParseBool(os.Getenv("EVENT_ARCHIVE_ENABLED")); err == nil {
+		config.Enabled = enabled
+	}
+	if days, err := strconv.Atoi(os.Getenv("EVENT_ARCHIVE_RETENTION_DAYS")); err == nil && days >= 0 {
+		config.Retention = time.Duration(days) * 24 * time.Hour
+	}
+	if max, err := strconv.Atoi(os.Getenv("EVENT_REPLAY_MAX_EVENTS")); err == nil && max > 0 {
+		config.MaxReplay = max
+	}
+
+	return config
+}
+
+// TypePattern converts a Filter's type to a SQL LIKE pattern
+func TypePattern(eventType string) string {
+	if eventType == "" {
+		return ""
+	}
+	prefix, wildcard := strings.CutSuffix(eventType, "*")
+	pattern := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(prefix)
+	if wildcard {
+		pattern += "%"
+	}
+	return pattern
+}
@@ -0,0 +1,246 @@
+package eventarchive
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"apis/payments/services/batching"
+	"apis/payments/services/events"
+	"apis/payments/services/jobs"
+	"apis/payments/services/merchantwebhooks"
+	"apis/payments/services/tenancy"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Store persists archived events
+type Store interface {
+	// ArchiveEvent stores an event, ignoring events already stored
+	ArchiveEvent(ctx context.Context, record *Record) error
+	ListArchivedEvents(ctx context.Context, filter Filter) ([]*Record, error)
+	PurgeArchivedEvents(ctx context.Context, before time.Time) (int64, error)
+}
+
+// TopicPublisher publishes events to a named Kafka topic
+type TopicPublisher interface {
+	PublishTo(ctx context.Context, topic string, event *events.Event) error
+}
+
+// Endpoints queues events for tenants' webhook endpoints
+type Endpoints interface {
+	GetEndpoint(ctx context.Context, tenantID, id string) (*merchantwebhooks.Endpoint, error)
+	Requeue(ctx context.Context, endpoint *merchantwebhooks.Endpoint, event *events.Event) error
+}
+
+// maxSummaries is how many events a dry run lists
+const maxSummaries = 100
+
+// Service keeps every event this service emits and replays them on
+// request. It is an events.Publisher: events are archived for the tenant
+// the context acts for as they are published.
+type Service struct {
+	store     Store
+	topics    TopicPublisher
+	endpoints Endpoints
+	config    *Config
+	batches   *batching.Controller
+	tracer    trace.Tracer
+}
+
+// NewService creates a new event archive. Without a topic publisher, events
+// can only be replayed to webhook endpoints.
+func NewService(store Store, topics TopicPublisher, endpoints Endpoints, config *Config) *Service {
+	return &Service{
+		store:     store,
+		topics:    topics,
+		endpoints: endpoints,
+		config:    config,
+		batches:   batching.NewController(batching.DefaultLimits()),
+		tracer:    otel.Tracer("payments.eventarchive"),
+	}
+}
+
+// UseBatchLimits sets the bounds the number of events a replay reads and
+// publishes at a time adapts within. Events are published in order, so
+// only the size adapts, not the concurrency.
+func (s *Service) UseBatchLimits(limits *batching.Limits) {
+	s.batches = batching.NewController(limits)
+}
+
+// BatchStats returns the replay batch size as last adapted
+func (s *Service) BatchStats() batching.Stats {
+	return s.batches.Stats()
+}
+
+// Enabled reports whether events are archived
+func (s *Service) Enabled() bool {
+	return s.config.Enabled
+}
+
+// Publish archives an event
+func (s *Service) Publish(ctx context.Context, event *events.Event) error {
+	ctx, span := s.tracer.Start(ctx, "Publish")
+	defer span.End()
+
+	if err := s.store.ArchiveEvent(ctx, &Record{Event: event, TenantID: tenancy.ID(ctx)}); err != nil {
+		return fmt.Errorf("failed to archive %s event: %w", event.Type, err)
+	}
+	return nil
+}
+
+// List returns archived events, oldest first
+func (s *Service) List(ctx context.Context, filter Filter) ([]*Record, error) {
+	ctx, span := s.tracer.Start(ctx, "List")
+	defer span.End()
+
+	if filter.To.IsZero() {
+		filter.To = time.Now()
+	}
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 100
+	}
+	return s.store.ListArchivedEvents(ctx, filter)
+}
+
+// Replay publishes the archived events a request selects again, oldest
+// first, to a topic or a webhook endpoint, in batches whose size adapts to
+// how long publishing them takes. Replayed events keep their IDs. A dry run
+// counts and lists the events without publishing them.
+func (s *Service) Replay(ctx context.Context, req ReplayRequest) (*ReplayResult, error) {
+	ctx, span := s.tracer.Start(ctx, "Replay")
+	defer span.End()
+
+	filter, err := s.replayFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var endpoint *merchantwebhooks.Endpoint
+	switch {
+	case req.EndpointID != "":
+		// Endpoints only receive their own tenant's events
+		if endpoint, err = s.endpoints.GetEndpoint(ctx, req.TenantID, req.EndpointID); err != nil {
+			return nil, err
+		}
+	case s.topics == nil:
+		return nil, ErrTopicsUnavailable
+	}
+
+	limit := req.Limit
+	if limit <= 0 || limit > s.config.MaxReplay {
+		limit = s.config.MaxReplay
+	}
+
+	result := &ReplayResult{DryRun: req.DryRun}
+	defer func() { result.Batch = s.batches.Stats() }()
+	for {
+		start, replayed := time.Now(), result.Replayed
+		filter.Limit = s.batches.Size()
+		records, err := s.store.ListArchivedEvents(ctx, filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list archived events: %w", err)
+		}
+
+		for _, record := range records {
+			event := record.Event
+			if endpoint != nil && !endpoint.Subscribes(event.Type) {
+				result.Skipped++
+				continue
+			}
+			if result.Matched == limit {
+				result.Truncated = true
+				return result, nil
+			}
+			result.Matched++
+
+			if req.DryRun {
+				if len(result.Events) < maxSummaries {
+					result.Events = append(result.Events, &Summary{ID: event.ID, Type: event.Type, Subject: event.Subject, Time: event.Time})
+				}
+				continue
+			}
+
+			if endpoint != nil {
+				err = s.endpoints.Requeue(ctx, endpoint, event)
+			} else {
+				err = s.topics.PublishTo(ctx, req.Topic, event)
+			}
+			if err != nil {
+				s.batches.Observe(batching.Result{Items: result.Replayed - replayed + 1, Failures: 1, Latency: time.Since(start)})
+				return result, fmt.Errorf("failed to replay event %s after %d of them: %w", event.ID, result.Replayed, err)
+			}
+			result.Replayed++
+		}
+		if !req.DryRun {
+			s.batches.Observe(batching.Result{Items: len(records), Latency: time.Since(start)})
+		}
+
+		if len(records) < filter.Limit {
+			return result, nil
+		}
+		last := records[len(records)-1]
+		filter.After = &Cursor{Time: last.Event.Time, ID: last.Event.ID}
+	}
+}
+
+// Purge removes events older than the retention period
+func (s *Service) Purge(ctx context.Context, now time.Time) (int64, error) {
+	ctx, span := s.tracer.Start(ctx, "Purge")
+	defer span.End()
+
+	if s.config.Retention <= 0 {
+		return 0, nil
+	}
+	return s.store.PurgeArchivedEvents(ctx, now.Add(-s.config.Retention))
+}
+
+// Job returns the daily purge of events past the retention period, or nil
+// when events are kept forever
+func (s *Service) Job() *jobs.Job {
+	if !s.config.Enabled || s.config.Retention <= 0 {
+		return nil
+	}
+
+	return &jobs.Job{
+		Name:     "event-archive-purge",
+		Schedule: "30 3 * * *",
+		Run: func(ctx context.Context) error {
+			purged, err := s.Purge(ctx, time.Now())
+			if purged > 0 {
+				log.Printf("Purged %d archived events", purged)
+			}
+			return err
+		},
+	}
+}
+
+// replayFilter validates a replay request and returns its filter
+func (s *Service) replayFilter(req ReplayRequest) (Filter, error) {
+	if req.From == nil {
+		return Filter{}, fmt.Errorf("%w: from is required", ErrInvalidReplay)
+	}
+	to := time.Now()
+	if req.To != nil {
+		to = *req.To
+	}
+	if !req.From.Before(to) {
+		return Filter{}, fmt.Errorf("%w: from must be before to", ErrInvalidReplay)
+	}
+	if (req.Topic == "") == (req.EndpointID == "") {
+		return Filter{}, fmt.Errorf("%w: set either topic or endpoint_id", ErrInvalidReplay)
+	}
+	if req.EndpointID != "" && req.TenantID == "" {
+		return Filter{}, fmt.Errorf("%w: tenant_id is required to replay to an endpoint", ErrInvalidReplay)
+	}
+
+	return Filter{
+		From:     *req.From,
+		To:       to,
+		TenantID: req.TenantID,
+		Type:     req.Type,
+		Subject:  req.Subject,
+	}, nil
+}
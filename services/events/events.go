@@ -0,0 +1,116 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"apis/payments/services/requestid"
+
+	"github.com/google/uuid"
+)
+
+// SpecVersion is the CloudEvents specification version of emitted events
+const SpecVersion = "1.0"
+
+// Event is a CloudEvents envelope for an event this service emits
+type Event struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            json.RawMessage `json:"data"`
+
+	// RequestID is the requestid extension attribute: the X-Request-ID of
+	// the API call or webhook delivery that caused the event
+	RequestID string `json:"requestid,omitempty"`
+}
+
+// Publisher delivers events to consumers
+type Publisher interface {
+	Publish(ctx context.Context, event *Event) error
+}
+
+// LogPublisher writes events to the service log. It is used until a broker
+// is configured.
+type LogPublisher struct{}
+
+// Publish logs the event
+func (LogPublisher) Publish(ctx context.Context, event *Event) error {
+	log.Printf("Event %s type=%s source=%s subject=%s requestid=%s", event.ID, event.Type, event.Source, event.Subject, event.RequestID)
+	return nil
+}
+
+// MultiPublisher fans an event out to several publishers
+type MultiPublisher []Publisher
+
+// Publish delivers the event to every publisher, returning the first error
+func (m MultiPublisher) Publish(ctx context.Context, event *Event) error {
+	var firstErr error
+	for _, publisher := range m {
+		if err := publisher.Publish(ctx, event); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Emitter builds events stamped with this deployment's source and publishes them
+type Emitter struct {
+	source    *Source
+	publisher Publisher
+}
+
+// NewEmitter creates a new emitter
+func NewEmitter(source *Source, publisher Publisher) *Emitter {
+	return &Emitter{
+		source:    source,
+		publisher: publisher,
+	}
+}
+
+// Emit publishes an event about a resource, e.g.
+// Emit(ctx, "refunds", "payments.auto_refund.created", refund.ID, refund)
+func (e *Emitter) Emit(ctx context.Context, resource, eventType, subject string, data any) error {
+	return e.publish(ctx, uuid.NewString(), time.Now().UTC(), resource, eventType, subject, data)
+}
+
+// Relay publishes an event about a change made at a provider. It keeps the
+// provider event's ID and time, so consumers can deduplicate the copies
+// published when the provider redelivers the event.
+func (e *Emitter) Relay(ctx context.Context, id string, occurred time.Time, resource, eventType, subject string, data any) error {
+	return e.publish(ctx, id, occurred.UTC(), resource, eventType, subject, data)
+}
+
+// publish builds and publishes an event
+func (e *Emitter) publish(ctx context.Context, id string, occurred time.Time, resource, eventType, subject string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+
+	event := &Event{
+		SpecVersion:     SpecVersion,
+		ID:              id,
+		Source:          e.source.For(resource),
+		Type:            eventType,
+		Subject:         subject,
+		Time:            occurred,
+		DataContentType: "application/json",
+		Data:            payload,
+	}
+	if id, ok := requestid.FromContext(ctx); ok {
+		event.RequestID = id
+	}
+
+	if err := e.publisher.Publish(ctx, event); err != nil {
+		return fmt.Errorf("failed to publish %s event: %w", eventType, err)
+	}
+
+	return nil
+}
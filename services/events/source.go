@@ -0,0 +1,69 @@
+package events
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// DefaultSourcePrefix is the CloudEvents source prefix used when none is configured
+const DefaultSourcePrefix = "/payments"
+
+// Source builds CloudEvents source URIs for the resources this service emits
+// events about, e.g. //payments.prod.eu-west-1.magebase/charges
+type Source struct {
+	prefix string
+}
+
+// NewSource creates a source from a URI-reference prefix
+func NewSource(prefix string) (*Source, error) {
+	prefix = strings.TrimRight(prefix, "/")
+	if prefix == "" {
+		return nil, fmt.Errorf("event source prefix cannot be empty")
+	}
+
+	if _, err := url.Parse(prefix); err != nil {
+		return nil, fmt.Errorf("invalid event source prefix %q: %w", prefix, err)
+	}
+
+	return &Source{prefix: prefix}, nil
+}
+
+// LoadSource reads the source prefix from EVENT_SOURCE_PREFIX. If it is not
+// set but DEPLOY_ENVIRONMENT is, the prefix is derived from the environment,
+// region and EVENT_SOURCE_DOMAIN so each deployment has a distinct origin.
+func LoadSource() (*Source, error) {
+	if prefix := os.Getenv("EVENT_SOURCE_PREFIX"); prefix != "" {
+		return NewSource(prefix)
+	}
+
+	environment := os.Getenv("DEPLOY_ENVIRONMENT")
+	if environment == "" {
+		return NewSource(DefaultSourcePrefix)
+	}
+
+	host := "payments." + environment
+	if region := os.Getenv("DEPLOY_REGION"); region != "" {
+		host += "." + region
+	}
+	if domain := os.Getenv("EVENT_SOURCE_DOMAIN"); domain != "" {
+		host += "." + domain
+	}
+
+	return NewSource("//" + host)
+}
+
+// For returns the source URI for a resource such as "charges"
+func (s *Source) For(resource string) string {
+	resource = strings.Trim(resource, "/")
+	if resource == "" {
+		return s.prefix
+	}
+	return s.prefix + "/" + resource
+}
+
+// String returns the source prefix
+func (s *Source) String() string {
+	return s.prefix
+}
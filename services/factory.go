@@ -4,8 +4,6 @@ import (
 	"fmt"
 	"os"
 	"strings"
-
-	"github.com/magebase/payments/services/stripe"
 )
 
 // Global factory instance
@@ -17,12 +15,23 @@ func InitializeFactory() {
 	
 	// Register Stripe provider
 	globalFactory.RegisterProvider("stripe", func(config map[string]interface{}) (PaymentGateway, error) {
-		return stripe.NewStripeGateway(config)
+		return NewStripeGateway(config)
+	})
+	
+	// Register Paddle provider
+	globalFactory.RegisterProvider("paddle", func(config map[string]interface{}) (PaymentGateway, error) {
+		return NewPaddleGateway(config)
 	})
 	
-	// TODO: Register additional providers as they are implemented
-	// globalFactory.RegisterProvider("paddle", paddle.NewPaddleGateway)
-	// globalFactory.RegisterProvider("square", square.NewSquareGateway)
+	// Register Square provider
+	globalFactory.RegisterProvider("square", func(config map[string]interface{}) (PaymentGateway, error) {
+		return NewSquareGateway(config)
+	})
+	
+	// Register PayPal provider
+	globalFactory.RegisterProvider("paypal", func(config map[string]interface{}) (PaymentGateway, error) {
+		return NewPayPalGateway(config)
+	})
 }
 
 // GetFactory returns the global payment gateway factory
@@ -33,16 +42,33 @@ func GetFactory() *DefaultProviderFactory {
 	return globalFactory
 }
 
+// UseRoutingPolicy sets the policy that ranks providers for gateway charges
+func (f *DefaultProviderFactory) UseRoutingPolicy(policy RoutingPolicy) {
+	f.routingPolicy = policy
+}
+
+// RoutingPolicy returns the policy that ranks providers for gateway charges,
+// or nil when charges go to the tenant's default provider
+func (f *DefaultProviderFactory) RoutingPolicy() RoutingPolicy {
+	return f.routingPolicy
+}
+
 // CreateGatewayFromEnv creates a payment gateway from environment variables
 func CreateGatewayFromEnv() (PaymentGateway, error) {
-	factory := GetFactory()
-	
 	// Get provider from environment
 	provider := strings.ToLower(os.Getenv("PAYMENT_PROVIDER"))
 	if provider == "" {
 		provider = "stripe" // Default to Stripe
 	}
 	
+	return CreateProviderGatewayFromEnv(provider)
+}
+
+// CreateProviderGatewayFromEnv creates a gateway for one provider from its
+// environment variables, acting on the platform's account
+func CreateProviderGatewayFromEnv(provider string) (PaymentGateway, error) {
+	factory := GetFactory()
+	
 	// Build configuration from environment
 	config := buildConfigFromEnv(provider)
 	
@@ -66,20 +92,27 @@ func buildConfigFromEnv(provider string) map[string]interface{} {
 	
 	switch provider {
 	case "stripe":
-		config["api_key"] = os.Getenv("STRIPE_API_KEY")
+		config["api_key"] = os.Getenv("STRIPE_SECRET_KEY")
 		config["webhook_secret"] = os.Getenv("STRIPE_WEBHOOK_SECRET")
 		config["publishable_key"] = os.Getenv("STRIPE_PUBLISHABLE_KEY")
 		
 	case "paddle":
-		config["vendor_id"] = os.Getenv("PADDLE_VENDOR_ID")
-		config["vendor_auth_code"] = os.Getenv("PADDLE_VENDOR_AUTH_CODE")
+		config["api_key"] = os.Getenv("PADDLE_API_KEY")
+		config["webhook_secret"] = os.Getenv("PADDLE_WEBHOOK_SECRET")
 		config["environment"] = os.Getenv("PADDLE_ENVIRONMENT") // sandbox or production
 		
 	case "square":
 		config["application_id"] = os.Getenv("SQUARE_APPLICATION_ID")
 		config["access_token"] = os.Getenv("SQUARE_ACCESS_TOKEN")
+		config["location_id"] = os.Getenv("SQUARE_LOCATION_ID") // optional, defaults to the main location
 		config["environment"] = os.Getenv("SQUARE_ENVIRONMENT") // sandbox or production
 		
+	case "paypal":
+		config["client_id"] = os.Getenv("PAYPAL_CLIENT_ID")
+		config["client_secret"] = os.Getenv("PAYPAL_CLIENT_SECRET")
+		config["webhook_id"] = os.Getenv("PAYPAL_WEBHOOK_ID")
+		config["environment"] = os.Getenv("PAYPAL_ENVIRONMENT") // sandbox or production
+		
 	default:
 		// For unknown providers, try to get generic config
 		config["api_key"] = os.Getenv("PAYMENT_API_KEY")
@@ -99,6 +132,8 @@ func ValidateProviderConfig(provider string, config map[string]interface{}) erro
 		return validatePaddleConfig(config)
 	case "square":
 		return validateSquareConfig(config)
+	case "paypal":
+		return validatePayPalConfig(config)
 	default:
 		return &UnsupportedProviderError{Provider: provider}
 	}
@@ -121,14 +156,9 @@ func validateStripeConfig(config map[string]interface{}) error {
 
 // validatePaddleConfig validates Paddle configuration
 func validatePaddleConfig(config map[string]interface{}) error {
-	vendorID, ok := config["vendor_id"].(string)
-	if !ok || vendorID == "" {
-		return &InvalidConfigError{Message: "paddle vendor_id is required"}
-	}
-	
-	vendorAuthCode, ok := config["vendor_auth_code"].(string)
-	if !ok || vendorAuthCode == "" {
-		return &InvalidConfigError{Message: "paddle vendor_auth_code is required"}
+	apiKey, ok := config["api_key"].(string)
+	if !ok || apiKey == "" {
+		return &InvalidConfigError{Message: "paddle api_key is required"}
 	}
 	
 	environment, ok := config["environment"].(string)
@@ -140,6 +170,14 @@ func validatePaddleConfig(config map[string]interface{}) error {
 		return &InvalidConfigError{Message: "paddle environment must be 'sandbox' or 'production'"}
 	}
 	
+	// Paddle API keys name the environment they belong to
+	if environment == "sandbox" && strings.HasPrefix(apiKey, "pdl_live_") {
+		return &InvalidConfigError{Message: "paddle live api_key cannot be used with the sandbox environment"}
+	}
+	if environment == "production" && strings.HasPrefix(apiKey, "pdl_sdbx_") {
+		return &InvalidConfigError{Message: "paddle sandbox api_key cannot be used with the production environment"}
+	}
+	
 	return nil
 }
 
@@ -167,6 +205,30 @@ func validateSquareConfig(config map[string]interface{}) error {
 	return nil
 }
 
+// validatePayPalConfig validates PayPal configuration
+func validatePayPalConfig(config map[string]interface{}) error {
+	clientID, ok := config["client_id"].(string)
+	if !ok || clientID == "" {
+		return &InvalidConfigError{Message: "paypal client_id is required"}
+	}
+	
+	clientSecret, ok := config["client_secret"].(string)
+	if !ok || clientSecret == "" {
+		return &InvalidConfigError{Message: "paypal client_secret is required"}
+	}
+	
+	environment, ok := config["environment"].(string)
+	if !ok || environment == "" {
+		return &InvalidConfigError{Message: "paypal environment is required"}
+	}
+	
+	if environment != "sandbox" && environment != "production" {
+		return &InvalidConfigError{Message: "paypal environment must be 'sandbox' or 'production'"}
+	}
+	
+	return nil
+}
+
 // GetSupportedProviders returns a list of all supported payment providers
 func GetSupportedProviders() []string {
 	factory := GetFactory()
@@ -227,6 +289,10 @@ func SupportsFeature(provider, feature string) bool {
 		return capabilities.SupportsConnect
 	case "tax":
 		return capabilities.SupportsTax
+	case "invoices":
+		return capabilities.SupportsInvoices
+	case "payment_links":
+		return capabilities.SupportsPaymentLinks
 	default:
 		return false
 	}
@@ -240,4 +306,22 @@ func GetProviderLimits(provider string) (minAmount, maxAmount int64, currencies
 	}
 	
 	return capabilities.MinChargeAmount, capabilities.MaxChargeAmount, capabilities.SupportedCurrencies, nil
-}
\ No newline at end of file
+}
+// Capabilities returns a provider's advertised capabilities without
+// configuring a gateway, so checks do not need its credentials
+func Capabilities(provider string) (GatewayCapabilities, error) {
+	switch provider {
+	case "stripe":
+		return (&StripeGateway{}).GetCapabilities(), nil
+	case "paddle":
+		return (&PaddleGateway{}).GetCapabilities(), nil
+	case "square":
+		return (&SquareGateway{}).GetCapabilities(), nil
+	case "paypal":
+		return (&PayPalGateway{}).GetCapabilities(), nil
+	case "simulator":
+		return (&SimulatorGateway{}).GetCapabilities(), nil
+	default:
+		return GatewayCapabilities{}, &UnsupportedProviderError{Provider: provider}
+	}
+}
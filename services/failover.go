@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+
+	"apis/payments/services/resilience"
+
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// Outcomes of trying a provider in a failover chain
+const (
+	FailoverSucceeded = "succeeded"
+	FailoverFailed    = "failed"
+	FailoverSkipped   = "skipped"
+)
+
+// ErrNoFailoverProvider is returned when no provider in a charge's chain can
+// take it
+var ErrNoFailoverProvider = errors.New("no provider in the route can take the charge")
+
+// infraDeclineCodes are declines caused by the provider or issuer being
+// unable to process the charge rather than by the card
+var infraDeclineCodes = map[stripego.DeclineCode]bool{
+	stripego.DeclineCodeProcessingError:    true,
+	stripego.DeclineCodeIssuerNotAvailable: true,
+	stripego.DeclineCodeTryAgainLater:      true,
+}
+
+// GatewayResolver returns a tenant's gateway for a provider
+type GatewayResolver interface {
+	Gateway(ctx context.Context, tenantID, provider string) (PaymentGateway, error)
+}
+
+// ChargeRouter returns the providers a charge is tried at, in order, and
+// whether a rule matched the charge
+type ChargeRouter interface {
+	Chain(currency, country string, amount int64) ([]string, bool)
+}
+
+// RoutingPolicy orders the providers a charge is tried at and learns from
+// whether each attempt was authorized. Given no candidates, it chooses among
+// every provider it knows.
+type RoutingPolicy interface {
+	Rank(ctx context.Context, req CreateChargeRequest, candidates []string) ([]string, error)
+	RecordAttempt(ctx context.Context, provider string, req CreateChargeRequest, authorized bool)
+}
+
+// FailoverAttempt is the outcome of trying one provider for a charge
+type FailoverAttempt struct {
+	Provider string `json:"provider"`
+	Outcome  string `json:"outcome"`
+	Error    string `json:"error,omitempty"`
+}
+
+// FailoverCharger creates charges through a chain of providers, falling back
+// to the next provider when one is down or declines for infrastructure
+// reasons. Card declines and invalid requests are returned at once, and
+// providers whose capabilities rule out the charge are skipped. Attempts a
+// provider answered are reported to the routing policy, if any.
+type FailoverCharger struct {
+	gateways GatewayResolver
+	router   ChargeRouter
+	policy   RoutingPolicy
+}
+
+// NewFailoverCharger creates a failover charger
+func NewFailoverCharger(gateways GatewayResolver, router ChargeRouter) *FailoverCharger {
+	return &FailoverCharger{
+		gateways: gateways,
+		router:   router,
+	}
+}
+
+// UsePolicy has a routing policy order each charge's providers. A nil
+// policy leaves chains in the order their rules give.
+func (f *FailoverCharger) UsePolicy(policy RoutingPolicy) {
+	f.policy = policy
+}
+
+// CreateCharge charges through the chain of the first matching rule, or the
+// tenant's default provider alone when no rule matches. With a routing
+// policy, the chain is reordered by it, and when no rule matches the policy
+// chooses among its providers with the default provider as the last resort.
+// It returns every provider tried along with the charge.
+func (f *FailoverCharger) CreateCharge(ctx context.Context, tenantID, defaultProvider string, req CreateChargeRequest) (*Charge, []FailoverAttempt, error) {
+	chain, ok := f.router.Chain(req.Currency, req.Country, req.Amount)
+	if f.policy != nil {
+		chain = f.rankChain(ctx, req, chain, ok, defaultProvider)
+	} else if !ok {
+		chain = []string{defaultProvider}
+	}
+
+	var attempts []FailoverAttempt
+	var lastErr error
+	for _, provider := range chain {
+		gateway, err := f.gateways.Gateway(ctx, tenantID, provider)
+		if err != nil {
+			attempts = append(attempts, FailoverAttempt{Provider: provider, Outcome: FailoverSkipped, Error: err.Error()})
+			continue
+		}
+		if reason := chargeUnsupported(gateway.GetCapabilities(), req); reason != "" {
+			attempts = append(attempts, FailoverAttempt{Provider: provider, Outcome: FailoverSkipped, Error: reason})
+			continue
+		}
+
+		charge, err := gateway.CreateCharge(ctx, req)
+		if f.policy != nil && (err == nil || !IsFailoverError(err)) {
+			f.policy.RecordAttempt(ctx, provider, req, err == nil)
+		}
+		if err == nil {
+			attempts = append(attempts, FailoverAttempt{Provider: provider, Outcome: FailoverSucceeded})
+			return charge, attempts, nil
+		}
+
+		attempts = append(attempts, FailoverAttempt{Provider: provider, Outcome: FailoverFailed, Error: err.Error()})
+		if !IsFailoverError(err) {
+			return nil, attempts, err
+		}
+		lastErr = err
+	}
+
+	if lastErr != nil {
+		return nil, attempts, fmt.Errorf("every provider in the route failed: %w", lastErr)
+	}
+	return nil, attempts, ErrNoFailoverProvider
+}
+
+// rankChain orders a matched rule's chain by the routing policy, or has the
+// policy choose providers when no rule matched. A policy that fails or
+// returns nothing leaves the chain as it would be without one.
+func (f *FailoverCharger) rankChain(ctx context.Context, req CreateChargeRequest, chain []string, matched bool, defaultProvider string) []string {
+	if !matched {
+		chain = []string{defaultProvider}
+	}
+
+	var candidates []string
+	if matched {
+		candidates = chain
+	}
+	ranked, err := f.policy.Rank(ctx, req, candidates)
+	if err != nil {
+		log.Printf("Warning: routing policy failed, keeping the route's order: %v", err)
+		return chain
+	}
+	if len(ranked) == 0 {
+		return chain
+	}
+	if !matched && !listed(ranked, defaultProvider) {
+		ranked = append(ranked, defaultProvider)
+	}
+	return ranked
+}
+
+// IsFailoverError reports whether a failed charge can safely be sent to
+// another provider: the provider was not reached (an open circuit breaker or
+// a connection that could not be made), refused the request unprocessed
+// (429 or 503), or declined it for infrastructure reasons. Timeouts and other
+// 5xx responses are ambiguous, since the charge may have gone through, so
+// they are not failed over.
+func IsFailoverError(err error) bool {
+	if errors.Is(err, resilience.ErrCircuitOpen) {
+		return true
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+
+	var stripeErr *stripego.Error
+	if errors.As(err, &stripeErr) {
+		return unprocessedStatus(stripeErr.HTTPStatusCode) ||
+			infraDeclineCodes[stripeErr.DeclineCode] ||
+			stripeErr.Code == stripego.ErrorCodeProcessingError
+	}
+	var declineErr interface{ GetDeclineCode() string }
+	if errors.As(err, &declineErr) && infraDeclineCodes[stripego.DeclineCode(declineErr.GetDeclineCode())] {
+		return true
+	}
+	var statusErr interface{ HTTPStatus() int }
+	if errors.As(err, &statusErr) {
+		return unprocessedStatus(statusErr.HTTPStatus())
+	}
+
+	return false
+}
+
+// unprocessedStatus reports whether a provider response status means the
+// request was refused without being processed
+func unprocessedStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable
+}
+
+// chargeUnsupported returns why a gateway's capabilities rule out a charge,
+// or "" when they allow it
+func chargeUnsupported(capabilities GatewayCapabilities, req CreateChargeRequest) string {
+	switch {
+	case !capabilities.SupportsCharges:
+		return "provider does not support charges"
+	case capabilities.MinChargeAmount > 0 && req.Amount < capabilities.MinChargeAmount:
+		return "amount is below the provider's minimum"
+	case capabilities.MaxChargeAmount > 0 && req.Amount > capabilities.MaxChargeAmount:
+		return "amount is above the provider's maximum"
+	case !listed(capabilities.SupportedCurrencies, req.Currency):
+		return "provider does not support the currency"
+	}
+	return ""
+}
+
+// listed reports whether a value is in a capability list, an empty list
+// allowing anything
+func listed(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
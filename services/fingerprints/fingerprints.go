@@ -0,0 +1,75 @@
+package fingerprints
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"apis/payments/services/config"
+)
+
+// Decisions on a card, from weakest to strongest
+const (
+	DecisionAllow = "allow"
+	DecisionFlag  = "flag"
+	DecisionBlock = "block"
+)
+
+// EventCardFlagged is emitted when a card being attached or charged is
+// flagged or blocked
+const EventCardFlagged = "payments.card.flagged"
+
+// ErrBlocked is returned when a card is blocked by its fingerprint's history
+var ErrBlocked = errors.New("card blocked by fingerprint checks")
+
+// Config holds the thresholds a card's fingerprint is checked against. A
+// threshold of 0 disables it.
+type Config struct {
+	Enabled bool
+
+	// Shared cards: other customers of the tenant that saved the same card
+	SharedCustomersFlag  int64
+	SharedCustomersBlock int64
+
+	// Chargebacks: disputes on the card's charges that weren't won
+	ChargebacksFlag    int64
+	ChargebacksBlock   int64
+	ChargebackLookback time.Duration
+}
+
+// LoadConfig loads fingerprint check configuration from environment variables
+func LoadConfig() *Config {
+	return &Config{
+		Enabled:              os.Getenv("CARD_FINGERPRINT_CHECKS_ENABLED") == "true",
+		SharedCustomersFlag:  int64(config.Int("CARD_FINGERPRINT_SHARED_CUSTOMERS_FLAG", 3)),
+		SharedCustomersBlock: int64(config.Int("CARD_FINGERPRINT_SHARED_CUSTOMERS_BLOCK", 10)),
+		ChargebacksFlag:      int64(config.Int("CARD_FINGERPRINT_CHARGEBACKS_FLAG", 1)),
+		ChargebacksBlock:     int64(config.Int("CARD_FINGERPRINT_CHARGEBACKS_BLOCK", 2)),
+		ChargebackLookback:   time.Duration(config.Int("CARD_FINGERPRINT_CHARGEBACK_LOOKBACK_DAYS", 365)) * 24 * time.Hour,
+	}
+}
+
+// Assessment is the verdict on a card fingerprint for one customer
+type Assessment struct {
+	Fingerprint string `json:"fingerprint"`
+	TenantID    string `json:"tenant_id"`
+	CustomerID  string `json:"customer_id,omitempty"`
+	// SharedCustomers is the number of other customers that saved the card
+	SharedCustomers int64    `json:"shared_customers"`
+	Chargebacks     int64    `json:"chargebacks"`
+	Decision        string   `json:"decision"`
+	Reasons         []string `json:"reasons"`
+}
+
+// Store counts how a card fingerprint has been used across the tenant
+type Store interface {
+	CountFingerprintCustomers(ctx context.Context, tenantID, fingerprint, excludeCustomerID string) (int64, error)
+	CountFingerprintChargebacks(ctx context.Context, tenantID, fingerprint string, since time.Time) (int64, error)
+}
+
+// stronger reports whether decision a outranks decision b
+func stronger(a, b string) bool {
+	rank := map[string]int{DecisionAllow: 0, DecisionFlag: 1, DecisionBlock: 2}
+	return rank[a] > rank[b]
+}
@@ -0,0 +1,176 @@
+package fingerprints
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"apis/payments/services/events"
+	"apis/payments/services/fraud"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Service checks cards by their fingerprint before they are attached or
+// charged. A card saved by many customers, or with chargebacks against it,
+// is likely shared or stolen: it is flagged, or blocked past the stricter
+// thresholds.
+type Service struct {
+	store   Store
+	emitter *events.Emitter
+	config  *Config
+	tracer  trace.Tracer
+}
+
+// NewService creates a new fingerprint check service
+func NewService(store Store, emitter *events.Emitter, config *Config) *Service {
+	if config == nil {
+		config = LoadConfig()
+	}
+
+	return &Service{
+		store:   store,
+		emitter: emitter,
+		config:  config,
+		tracer:  otel.Tracer("payments.fingerprints"),
+	}
+}
+
+// Enabled reports whether cards are checked
+func (s *Service) Enabled() bool {
+	return s.config.Enabled
+}
+
+// Assess counts the other customers that saved a card and the chargebacks
+// against it, and decides whether the customer may use it. The strongest
+// decision of the two wins.
+func (s *Service) Assess(ctx context.Context, tenantID, fingerprint, customerID string) (*Assessment, error) {
+	ctx, span := s.tracer.Start(ctx, "Assess")
+	defer span.End()
+
+	assessment := &Assessment{
+		Fingerprint: fingerprint,
+		TenantID:    tenantID,
+		CustomerID:  customerID,
+		Decision:    DecisionAllow,
+		Reasons:     []string{},
+	}
+	if fingerprint == "" {
+		return assessment, nil
+	}
+
+	shared, err := s.store.CountFingerprintCustomers(ctx, tenantID, fingerprint, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count customers sharing card: %w", err)
+	}
+	chargebacks, err := s.store.CountFingerprintChargebacks(ctx, tenantID, fingerprint, time.Now().Add(-s.config.ChargebackLookback))
+	if err != nil {
+		return nil, fmt.Errorf("failed to count card chargebacks: %w", err)
+	}
+	assessment.SharedCustomers = shared
+	assessment.Chargebacks = chargebacks
+
+	signals := []struct {
+		count       int64
+		flag, block int64
+		reason      string
+	}{
+		{shared, s.config.SharedCustomersFlag, s.config.SharedCustomersBlock,
+			fmt.Sprintf("card saved by %d other customers", shared)},
+		{chargebacks, s.config.ChargebacksFlag, s.config.ChargebacksBlock,
+			fmt.Sprintf("%d chargebacks on the card in the last %s", chargebacks, s.config.ChargebackLookback)},
+	}
+	for _, signal := range signals {
+		decision := ""
+		switch {
+		case signal.block > 0 && signal.count >= signal.block:
+			decision = DecisionBlock
+		case signal.flag > 0 && signal.count >= signal.flag:
+			decision = DecisionFlag
+		default:
+			continue
+		}
+
+		assessment.Reasons = append(assessment.Reasons, signal.reason)
+		if stronger(decision, assessment.Decision) {
+			assessment.Decision = decision
+		}
+	}
+
+	return assessment, nil
+}
+
+// Check assesses a card about to be attached or charged, emitting a
+// card.flagged event unless it is allowed. A blocked card returns ErrBlocked
+// along with its assessment. Cards aren't checked while checks are disabled.
+func (s *Service) Check(ctx context.Context, tenantID, fingerprint, customerID string) (*Assessment, error) {
+	ctx, span := s.tracer.Start(ctx, "Check")
+	defer span.End()
+
+	if !s.config.Enabled {
+		return &Assessment{Fingerprint: fingerprint, TenantID: tenantID, CustomerID: customerID, Decision: DecisionAllow, Reasons: []string{}}, nil
+	}
+
+	assessment, err := s.Assess(ctx, tenantID, fingerprint, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if assessment.Decision == DecisionAllow {
+		return assessment, nil
+	}
+
+	if err := s.emitter.Emit(ctx, "payment_methods", EventCardFlagged, fingerprint, assessment); err != nil {
+		// The decision stands; only the notification is lost
+		log.Printf("Failed to emit %s for card %s: %v", EventCardFlagged, fingerprint, err)
+	}
+
+	if assessment.Decision == DecisionBlock {
+		return assessment, fmt.Errorf("%w: %s", ErrBlocked, strings.Join(assessment.Reasons, "; "))
+	}
+	return assessment, nil
+}
+
+// FraudRule screens charges with the fingerprint checks, holding flagged
+// cards for review and rejecting blocked ones
+type FraudRule struct {
+	service *Service
+}
+
+// NewFraudRule creates a fraud screening rule from the fingerprint checks
+func NewFraudRule(service *Service) *FraudRule {
+	return &FraudRule{service: service}
+}
+
+// Name returns the rule name
+func (r *FraudRule) Name() string {
+	return "card_fingerprint"
+}
+
+// Evaluate assesses the screened card for the screened customer
+func (r *FraudRule) Evaluate(ctx context.Context, screening *fraud.Screening) (*fraud.Finding, error) {
+	if !r.service.Enabled() || screening.CardFingerprint == "" {
+		return nil, nil
+	}
+
+	assessment, err := r.service.Assess(ctx, screening.TenantID, screening.CardFingerprint, screening.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+
+	decision := fraud.DecisionReview
+	switch assessment.Decision {
+	case DecisionAllow:
+		return nil, nil
+	case DecisionBlock:
+		decision = fraud.DecisionReject
+	}
+
+	return &fraud.Finding{
+		Rule:     r.Name(),
+		Decision: decision,
+		Reason:   strings.Join(assessment.Reasons, "; "),
+	}, nil
+}
@@ -0,0 +1,207 @@
+package fraud
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"apis/payments/services/config"
+	"apis/payments/services/stripe"
+)
+
+// Screening decisions, from weakest to strongest
+const (
+	DecisionAccept = "accept"
+	DecisionReview = "review"
+	DecisionReject = "reject"
+)
+
+// Review statuses
+const (
+	ReviewPending  = "pending"
+	ReviewApproved = "approved"
+	ReviewRejected = "rejected"
+	ReviewFailed   = "failed" // Approved, but the charge then failed at the provider
+)
+
+// Lists a screened value can be on
+const (
+	ListAllow = "allow"
+	ListDeny  = "deny"
+)
+
+// Types of values list entries match
+const (
+	EntryCustomer        = "customer"
+	EntryCardFingerprint = "card_fingerprint"
+	EntryIPAddress       = "ip_address"
+)
+
+// EventChargeFlagged is emitted when a charge is held for review
+const EventChargeFlagged = "payments.charge.flagged"
+
+// Errors returned by the fraud service
+var (
+	ErrRejected          = errors.New("charge rejected by fraud screening")
+	ErrReviewNotFound    = errors.New("fraud review not found")
+	ErrReviewNotPending  = errors.New("fraud review has already been decided")
+	ErrInvalidListEntry  = errors.New("invalid fraud list entry")
+	ErrListEntryNotFound = errors.New("fraud list entry not found")
+)
+
+// Config holds fraud screening configuration
+type Config struct {
+	Enabled bool
+
+	// Velocity: screenings per customer, card or IP within the window
+	VelocityWindow time.Duration
+	VelocityReview int64 // 0 disables
+	VelocityReject int64 // 0 disables
+
+	// Amount anomaly: a charge above Multiplier times the customer's average
+	// accepted charge in the same currency is reviewed
+	AmountBaseline   time.Duration
+	AmountMinHistory int64
+	AmountMultiplier float64
+
+	// Radar: the latest Stripe Radar risk score seen for the customer or card
+	RadarReviewScore int64 // 0 disables
+	RadarRejectScore int64 // 0 disables
+	RadarLookback    time.Duration
+}
+
+// LoadConfig loads fraud screening configuration from environment variables
+func LoadConfig() *Config {
+	return &Config{
+		Enabled:          os.Getenv("FRAUD_SCREENING_ENABLED") == "true",
+		VelocityWindow:   time.Duration(config.Int("FRAUD_VELOCITY_WINDOW_MINUTES", 60)) * time.Minute,
+		VelocityReview:   int64(config.Int("FRAUD_VELOCITY_REVIEW", 5)),
+		VelocityReject:   int64(config.Int("FRAUD_VELOCITY_REJECT", 10)),
+		AmountBaseline:   time.Duration(config.Int("FRAUD_AMOUNT_BASELINE_DAYS", 30)) * 24 * time.Hour,
+		AmountMinHistory: int64(config.Int("FRAUD_AMOUNT_MIN_HISTORY", 3)),
+		AmountMultiplier: config.Float("FRAUD_AMOUNT_MULTIPLIER", 5),
+		RadarReviewScore: int64(config.Int("FRAUD_RADAR_REVIEW_SCORE", 65)),
+		RadarRejectScore: int64(config.Int("FRAUD_RADAR_REJECT_SCORE", 85)),
+		RadarLookback:    time.Duration(config.Int("FRAUD_RADAR_LOOKBACK_DAYS", 30)) * 24 * time.Hour,
+	}
+}
+
+// Screening is a charge about to be created, as fraud rules see it
+type Screening struct {
+	ID              string    `json:"id"`
+	TenantID        string    `json:"tenant_id"`
+	CustomerID      string    `json:"customer_id,omitempty"`
+	CardFingerprint string    `json:"card_fingerprint,omitempty"`
+	IPAddress       string    `json:"ip_address,omitempty"`
+	Amount          int64     `json:"amount"`
+	Currency        string    `json:"currency"`
+	Decision        string    `json:"decision,omitempty"`
+	Findings        []Finding `json:"findings,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// Finding is one rule's verdict on a screening
+type Finding struct {
+	Rule     string `json:"rule"`
+	Decision string `json:"decision"`
+	Reason   string `json:"reason"`
+}
+
+// Assessment is the combined verdict of every rule on a screening
+type Assessment struct {
+	Decision string    `json:"decision"`
+	Findings []Finding `json:"findings"`
+}
+
+// Rule is a fraud check run against every screened charge. A rule returns
+// nil when it has nothing to report.
+type Rule interface {
+	Name() string
+	Evaluate(ctx context.Context, screening *Screening) (*Finding, error)
+}
+
+// Counts are the screenings seen for a charge's customer, card and IP
+type Counts struct {
+	Customer int64 `json:"customer"`
+	Card     int64 `json:"card"`
+	IP       int64 `json:"ip"`
+}
+
+// AmountHistory summarises a customer's accepted charges in one currency
+type AmountHistory struct {
+	Count   int64 `json:"count"`
+	Average int64 `json:"average"`
+}
+
+// RadarScore is the Stripe Radar assessment of a created charge
+type RadarScore struct {
+	ChargeID        string    `json:"charge_id"`
+	CustomerID      string    `json:"customer_id,omitempty"`
+	CardFingerprint string    `json:"card_fingerprint,omitempty"`
+	RiskScore       int64     `json:"risk_score"`
+	RiskLevel       string    `json:"risk_level,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// ListEntry allows or denies charges from a customer, card or IP address
+type ListEntry struct {
+	ID        string    `json:"id"`
+	List      string    `json:"list"`
+	Type      string    `json:"type"`
+	Value     string    `json:"value"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Review is a charge held until a reviewer approves or rejects it
+type Review struct {
+	ID            string               `json:"id"`
+	TenantID      string               `json:"tenant_id"`
+	ScreeningID   string               `json:"screening_id"`
+	CustomerID    string               `json:"customer_id,omitempty"`
+	Amount        int64                `json:"amount"`
+	Currency      string               `json:"currency"`
+	Request       stripe.ChargeRequest `json:"request"`
+	Findings      []Finding            `json:"findings"`
+	Status        string               `json:"status"`
+	DecidedBy     string               `json:"decided_by,omitempty"`
+	ChargeID      string               `json:"charge_id,omitempty"`
+	FailureReason string               `json:"failure_reason,omitempty"`
+	CreatedAt     time.Time            `json:"created_at"`
+	UpdatedAt     time.Time            `json:"updated_at"`
+	DecidedAt     *time.Time           `json:"decided_at,omitempty"`
+}
+
+// Store persists screenings, lists, Radar scores and reviews
+type Store interface {
+	RecordFraudScreening(ctx context.Context, screening *Screening) error
+	CountRecentFraudScreenings(ctx context.Context, screening *Screening, since time.Time) (Counts, error)
+	GetCustomerScreenedAmounts(ctx context.Context, tenantID, customerID, currency string, since time.Time) (AmountHistory, error)
+
+	CreateFraudListEntry(ctx context.Context, entry *ListEntry) (*ListEntry, error)
+	ListFraudListEntries(ctx context.Context, list string) ([]*ListEntry, error)
+	MatchFraudListEntries(ctx context.Context, screening *Screening) ([]*ListEntry, error)
+	DeleteFraudListEntry(ctx context.Context, id string) (bool, error)
+
+	RecordRadarScore(ctx context.Context, score *RadarScore) error
+	GetLatestRadarScore(ctx context.Context, customerID, cardFingerprint string, since time.Time) (*RadarScore, error)
+
+	CreateFraudReview(ctx context.Context, review *Review) (*Review, error)
+	GetFraudReview(ctx context.Context, id string) (*Review, error)
+	ListFraudReviews(ctx context.Context, tenantID, status string, limit int) ([]*Review, error)
+	DecideFraudReview(ctx context.Context, id, status, decidedBy string) (*Review, error)
+	RecordFraudReviewCharge(ctx context.Context, id, status, chargeID, failureReason string) (*Review, error)
+}
+
+// Charger creates charges that passed screening
+type Charger interface {
+	CreateCharge(ctx context.Context, tenantID string, request *stripe.ChargeRequest) (*stripe.Charge, error)
+}
+
+// stronger reports whether decision a outranks decision b
+func stronger(a, b string) bool {
+	rank := map[string]int{DecisionAccept: 0, DecisionReview: 1, DecisionReject: 2}
+	return rank[a] > rank[b]
+}
@@ -0,0 +1,175 @@
+package fraud
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// VelocityRule reviews or rejects charges when a customer, card or IP
+// address has been screened too often within the window
+type VelocityRule struct {
+	store  Store
+	config *Config
+}
+
+// NewVelocityRule creates a velocity rule
+func NewVelocityRule(store Store, config *Config) *VelocityRule {
+	return &VelocityRule{store: store, config: config}
+}
+
+// Name returns the rule name
+func (r *VelocityRule) Name() string {
+	return "velocity"
+}
+
+// Evaluate counts this charge along with the recent screenings sharing its
+// customer, card or IP address
+func (r *VelocityRule) Evaluate(ctx context.Context, screening *Screening) (*Finding, error) {
+	if r.config.VelocityReview <= 0 && r.config.VelocityReject <= 0 {
+		return nil, nil
+	}
+
+	counts, err := r.store.CountRecentFraudScreenings(ctx, screening, time.Now().Add(-r.config.VelocityWindow))
+	if err != nil {
+		return nil, fmt.Errorf("failed to count recent screenings: %w", err)
+	}
+
+	dimensions := []struct {
+		name  string
+		key   string
+		count int64
+	}{
+		{"customer", screening.CustomerID, counts.Customer},
+		{"card", screening.CardFingerprint, counts.Card},
+		{"IP address", screening.IPAddress, counts.IP},
+	}
+
+	var finding *Finding
+	for _, d := range dimensions {
+		if d.key == "" {
+			continue
+		}
+
+		projected := d.count + 1
+		decision := ""
+		switch {
+		case r.config.VelocityReject > 0 && projected > r.config.VelocityReject:
+			decision = DecisionReject
+		case r.config.VelocityReview > 0 && projected > r.config.VelocityReview:
+			decision = DecisionReview
+		default:
+			continue
+		}
+
+		if finding == nil || stronger(decision, finding.Decision) {
+			finding = &Finding{
+				Rule:     r.Name(),
+				Decision: decision,
+				Reason:   fmt.Sprintf("%d charges from this %s in the last %s", projected, d.name, r.config.VelocityWindow),
+			}
+		}
+	}
+
+	return finding, nil
+}
+
+// AmountAnomalyRule reviews charges far above a customer's usual amount
+type AmountAnomalyRule struct {
+	store  Store
+	config *Config
+}
+
+// NewAmountAnomalyRule creates an amount anomaly rule
+func NewAmountAnomalyRule(store Store, config *Config) *AmountAnomalyRule {
+	return &AmountAnomalyRule{store: store, config: config}
+}
+
+// Name returns the rule name
+func (r *AmountAnomalyRule) Name() string {
+	return "amount_anomaly"
+}
+
+// Evaluate compares the charge to the customer's average accepted charge in
+// the same currency. Customers with too little history are not judged.
+func (r *AmountAnomalyRule) Evaluate(ctx context.Context, screening *Screening) (*Finding, error) {
+	if screening.CustomerID == "" || r.config.AmountMultiplier <= 0 {
+		return nil, nil
+	}
+
+	since := time.Now().Add(-r.config.AmountBaseline)
+	history, err := r.store.GetCustomerScreenedAmounts(ctx, screening.TenantID, screening.CustomerID, screening.Currency, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer amount history: %w", err)
+	}
+
+	if history.Count < r.config.AmountMinHistory || history.Average <= 0 {
+		return nil, nil
+	}
+
+	limit := float64(history.Average) * r.config.AmountMultiplier
+	if float64(screening.Amount) <= limit {
+		return nil, nil
+	}
+
+	return &Finding{
+		Rule:     r.Name(),
+		Decision: DecisionReview,
+		Reason: fmt.Sprintf("amount %d is over %.1fx the customer's average of %d %s",
+			screening.Amount, r.config.AmountMultiplier, history.Average, screening.Currency),
+	}, nil
+}
+
+// RadarRule reviews or rejects charges from customers or cards Stripe Radar
+// recently scored as risky
+type RadarRule struct {
+	store  Store
+	config *Config
+}
+
+// NewRadarRule creates a Radar score rule
+func NewRadarRule(store Store, config *Config) *RadarRule {
+	return &RadarRule{store: store, config: config}
+}
+
+// Name returns the rule name
+func (r *RadarRule) Name() string {
+	return "radar"
+}
+
+// Evaluate checks the latest Radar score seen for the customer or card
+func (r *RadarRule) Evaluate(ctx context.Context, screening *Screening) (*Finding, error) {
+	if screening.CustomerID == "" && screening.CardFingerprint == "" {
+		return nil, nil
+	}
+	if r.config.RadarReviewScore <= 0 && r.config.RadarRejectScore <= 0 {
+		return nil, nil
+	}
+
+	since := time.Now().Add(-r.config.RadarLookback)
+	score, err := r.store.GetLatestRadarScore(ctx, screening.CustomerID, screening.CardFingerprint, since)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get latest Radar score: %w", err)
+	}
+
+	decision := ""
+	switch {
+	case r.config.RadarRejectScore > 0 && score.RiskScore >= r.config.RadarRejectScore:
+		decision = DecisionReject
+	case r.config.RadarReviewScore > 0 && score.RiskScore >= r.config.RadarReviewScore:
+		decision = DecisionReview
+	default:
+		return nil, nil
+	}
+
+	return &Finding{
+		Rule:     r.Name(),
+		Decision: decision,
+		Reason:   fmt.Sprintf("Radar scored charge %s at %d (%s)", score.ChargeID, score.RiskScore, score.RiskLevel),
+	}, nil
+}
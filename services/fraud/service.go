@@ -0,0 +1,397 @@
+package fraud
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"apis/payments/services/events"
+	"apis/payments/services/money"
+	"apis/payments/services/stripe"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Service screens charges before they are created, holding suspicious ones
+// for review and rejecting fraudulent ones
+type Service struct {
+	store   Store
+	charger Charger
+	emitter *events.Emitter
+	config  *Config
+	rules   []Rule
+	tracer  trace.Tracer
+}
+
+// NewService creates a new fraud screening service with the built-in
+// velocity, amount anomaly and Radar rules
+func NewService(store Store, charger Charger, emitter *events.Emitter, config *Config) *Service {
+	if config == nil {
+		config = LoadConfig()
+	}
+
+	s := &Service{
+		store:   store,
+		charger: charger,
+		emitter: emitter,
+		config:  config,
+		tracer:  otel.Tracer("payments.fraud"),
+	}
+	s.Use(NewVelocityRule(store, config))
+	s.Use(NewAmountAnomalyRule(store, config))
+	s.Use(NewRadarRule(store, config))
+
+	return s
+}
+
+// Use adds a rule run against every screened charge
+func (s *Service) Use(rule Rule) {
+	s.rules = append(s.rules, rule)
+}
+
+// Enabled reports whether charges are screened
+func (s *Service) Enabled() bool {
+	return s.config.Enabled
+}
+
+// Assess runs the lists and rules against a screening without recording it.
+// An allow-listed customer, card or IP address is accepted outright and a
+// deny-listed one rejected; otherwise the strongest rule decision wins. A
+// rule that fails is skipped so an outage can't block every charge.
+func (s *Service) Assess(ctx context.Context, screening *Screening) (*Assessment, error) {
+	ctx, span := s.tracer.Start(ctx, "Assess")
+	defer span.End()
+
+	entries, err := s.store.MatchFraudListEntries(ctx, screening)
+	if err != nil {
+		return nil, fmt.Errorf("failed to match fraud lists: %w", err)
+	}
+
+	assessment := &Assessment{Decision: DecisionAccept, Findings: []Finding{}}
+	for _, entry := range entries {
+		if entry.List == ListAllow {
+			assessment.Findings = []Finding{{
+				Rule:     "allow_list",
+				Decision: DecisionAccept,
+				Reason:   fmt.Sprintf("%s %s is allow-listed", entry.Type, entry.Value),
+			}}
+			return assessment, nil
+		}
+	}
+	for _, entry := range entries {
+		assessment.Decision = DecisionReject
+		assessment.Findings = append(assessment.Findings, Finding{
+			Rule:     "deny_list",
+			Decision: DecisionReject,
+			Reason:   fmt.Sprintf("%s %s is deny-listed", entry.Type, entry.Value),
+		})
+	}
+
+	for _, rule := range s.rules {
+		finding, err := rule.Evaluate(ctx, screening)
+		if err != nil {
+			log.Printf("Fraud rule %s failed, skipping it: %v", rule.Name(), err)
+			continue
+		}
+		if finding == nil {
+			continue
+		}
+
+		assessment.Findings = append(assessment.Findings, *finding)
+		if stronger(finding.Decision, assessment.Decision) {
+			assessment.Decision = finding.Decision
+		}
+	}
+
+	return assessment, nil
+}
+
+// Screen assesses a screening and records it. A rejected screening returns
+// ErrRejected; otherwise the caller acts on the returned decision.
+func (s *Service) Screen(ctx context.Context, screening *Screening) (*Assessment, error) {
+	ctx, span := s.tracer.Start(ctx, "Screen")
+	defer span.End()
+
+	assessment, err := s.Assess(ctx, screening)
+	if err != nil {
+		return nil, err
+	}
+
+	screening.ID = fmt.Sprintf("frs_%s", uuid.New().String())
+	screening.Decision = assessment.Decision
+	screening.Findings = assessment.Findings
+	if err := s.store.RecordFraudScreening(ctx, screening); err != nil {
+		return nil, fmt.Errorf("failed to record fraud screening: %w", err)
+	}
+
+	if assessment.Decision == DecisionReject {
+		return nil, fmt.Errorf("%w: %s", ErrRejected, summarize(assessment.Findings))
+	}
+
+	return assessment, nil
+}
+
+// CreateCharge screens a charge and creates it if it is accepted. A charge
+// screened for review is held and returned as a review instead; a rejected
+// one returns ErrRejected. Exactly one of the returned charge and review is
+// non-nil on success. With screening disabled the charge is created as is.
+func (s *Service) CreateCharge(ctx context.Context, screening *Screening, request *stripe.ChargeRequest) (*stripe.Charge, *Review, error) {
+	ctx, span := s.tracer.Start(ctx, "CreateCharge")
+	defer span.End()
+
+	if !s.config.Enabled {
+		charge, err := s.charger.CreateCharge(ctx, screening.TenantID, request)
+		return charge, nil, err
+	}
+
+	amount, err := money.ResolveAmount(request.Amount, request.AmountDecimal, request.Currency)
+	if err != nil {
+		return nil, nil, fmt.Errorf("validation failed: %w", err)
+	}
+	screening.Amount = amount
+	screening.Currency = strings.ToLower(request.Currency)
+	if screening.CustomerID == "" {
+		screening.CustomerID = request.CustomerID
+	}
+
+	assessment, err := s.Screen(ctx, screening)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if assessment.Decision == DecisionReview {
+		review, err := s.hold(ctx, screening, request)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, review, nil
+	}
+
+	charge, err := s.charge(ctx, screening.TenantID, screening.CardFingerprint, request)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return charge, nil, nil
+}
+
+// ApproveReview creates a held charge. The review is claimed before the
+// charge is created so two reviewers can't charge it twice; if the charge
+// then fails the review is marked failed.
+func (s *Service) ApproveReview(ctx context.Context, reviewID, reviewerID string) (*Review, error) {
+	ctx, span := s.tracer.Start(ctx, "ApproveReview")
+	defer span.End()
+
+	review, err := s.decide(ctx, reviewID, ReviewApproved, reviewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	charge, err := s.charge(ctx, review.TenantID, "", &review.Request)
+	if err != nil {
+		if _, recordErr := s.store.RecordFraudReviewCharge(ctx, review.ID, ReviewFailed, "", err.Error()); recordErr != nil {
+			log.Printf("Failed to record failed charge for fraud review %s: %v", review.ID, recordErr)
+		}
+		return nil, err
+	}
+
+	approved, err := s.store.RecordFraudReviewCharge(ctx, review.ID, ReviewApproved, charge.ID, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to record charge %s for fraud review: %w", charge.ID, err)
+	}
+
+	log.Printf("Fraud review %s approved by %s, created charge %s", review.ID, reviewerID, charge.ID)
+	return approved, nil
+}
+
+// RejectReview rejects a held charge without creating it
+func (s *Service) RejectReview(ctx context.Context, reviewID, reviewerID string) (*Review, error) {
+	ctx, span := s.tracer.Start(ctx, "RejectReview")
+	defer span.End()
+
+	rejected, err := s.decide(ctx, reviewID, ReviewRejected, reviewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Fraud review %s rejected by %s", rejected.ID, reviewerID)
+	return rejected, nil
+}
+
+// GetReview retrieves a fraud review
+func (s *Service) GetReview(ctx context.Context, reviewID string) (*Review, error) {
+	ctx, span := s.tracer.Start(ctx, "GetReview")
+	defer span.End()
+
+	review, err := s.store.GetFraudReview(ctx, reviewID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrReviewNotFound
+		}
+		return nil, err
+	}
+
+	return review, nil
+}
+
+// ListReviews lists a tenant's fraud reviews, newest first, optionally
+// filtered by status
+func (s *Service) ListReviews(ctx context.Context, tenantID, status string, limit int) ([]*Review, error) {
+	ctx, span := s.tracer.Start(ctx, "ListReviews")
+	defer span.End()
+
+	if tenantID == "" {
+		return nil, fmt.Errorf("tenant ID cannot be empty")
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 100
+	}
+
+	return s.store.ListFraudReviews(ctx, tenantID, status, limit)
+}
+
+// AddListEntry adds a customer, card fingerprint or IP address to the allow
+// or deny list
+func (s *Service) AddListEntry(ctx context.Context, entry *ListEntry) (*ListEntry, error) {
+	ctx, span := s.tracer.Start(ctx, "AddListEntry")
+	defer span.End()
+
+	entry.Value = strings.TrimSpace(entry.Value)
+	if entry.List != ListAllow && entry.List != ListDeny {
+		return nil, fmt.Errorf("%w: list must be %q or %q", ErrInvalidListEntry, ListAllow, ListDeny)
+	}
+	switch entry.Type {
+	case EntryCustomer, EntryCardFingerprint, EntryIPAddress:
+	default:
+		return nil, fmt.Errorf("%w: type must be %q, %q or %q", ErrInvalidListEntry, EntryCustomer, EntryCardFingerprint, EntryIPAddress)
+	}
+	if entry.Value == "" {
+		return nil, fmt.Errorf("%w: value is required", ErrInvalidListEntry)
+	}
+
+	entry.ID = fmt.Sprintf("frl_%s", uuid.New().String())
+	return s.store.CreateFraudListEntry(ctx, entry)
+}
+
+// ListEntries lists the entries on a list, or on both lists when list is empty
+func (s *Service) ListEntries(ctx context.Context, list string) ([]*ListEntry, error) {
+	ctx, span := s.tracer.Start(ctx, "ListEntries")
+	defer span.End()
+
+	return s.store.ListFraudListEntries(ctx, list)
+}
+
+// RemoveListEntry removes an allow or deny list entry
+func (s *Service) RemoveListEntry(ctx context.Context, id string) error {
+	ctx, span := s.tracer.Start(ctx, "RemoveListEntry")
+	defer span.End()
+
+	deleted, err := s.store.DeleteFraudListEntry(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrListEntryNotFound
+	}
+
+	return nil
+}
+
+// IngestRadarScore keeps the Radar risk score of a created charge so later
+// charges from the same customer or card are screened against it
+func (s *Service) IngestRadarScore(ctx context.Context, charge *stripe.Charge, cardFingerprint string) error {
+	ctx, span := s.tracer.Start(ctx, "IngestRadarScore")
+	defer span.End()
+
+	if charge.RiskScore <= 0 && charge.RiskLevel == "" {
+		return nil
+	}
+
+	return s.store.RecordRadarScore(ctx, &RadarScore{
+		ChargeID:        charge.ID,
+		CustomerID:      charge.CustomerID,
+		CardFingerprint: cardFingerprint,
+		RiskScore:       charge.RiskScore,
+		RiskLevel:       charge.RiskLevel,
+	})
+}
+
+// charge creates an accepted charge and ingests its Radar score
+func (s *Service) charge(ctx context.Context, tenantID, cardFingerprint string, request *stripe.ChargeRequest) (*stripe.Charge, error) {
+	charge, err := s.charger.CreateCharge(ctx, tenantID, request)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.IngestRadarScore(ctx, charge, cardFingerprint); err != nil {
+		// The charge went through; a missing score only weakens later screening
+		log.Printf("Failed to record Radar score for charge %s: %v", charge.ID, err)
+	}
+
+	return charge, nil
+}
+
+// hold queues a charge for review and emits a charge.flagged event
+func (s *Service) hold(ctx context.Context, screening *Screening, request *stripe.ChargeRequest) (*Review, error) {
+	review, err := s.store.CreateFraudReview(ctx, &Review{
+		ID:          fmt.Sprintf("frv_%s", uuid.New().String()),
+		TenantID:    screening.TenantID,
+		ScreeningID: screening.ID,
+		CustomerID:  screening.CustomerID,
+		Amount:      screening.Amount,
+		Currency:    screening.Currency,
+		Request:     *request,
+		Findings:    screening.Findings,
+		Status:      ReviewPending,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create fraud review: %w", err)
+	}
+
+	if err := s.emitter.Emit(ctx, "charges", EventChargeFlagged, review.ID, review); err != nil {
+		// The charge is already held, so a failed event must not release it
+		log.Printf("Failed to emit %s for fraud review %s: %v", EventChargeFlagged, review.ID, err)
+	}
+
+	return review, nil
+}
+
+// decide moves a pending review to approved or rejected
+func (s *Service) decide(ctx context.Context, reviewID, status, reviewerID string) (*Review, error) {
+	if reviewID == "" {
+		return nil, fmt.Errorf("review ID cannot be empty")
+	}
+	if reviewerID == "" {
+		return nil, fmt.Errorf("reviewer ID cannot be empty")
+	}
+
+	if _, err := s.GetReview(ctx, reviewID); err != nil {
+		return nil, err
+	}
+
+	review, err := s.store.DecideFraudReview(ctx, reviewID, status, reviewerID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrReviewNotPending
+		}
+		return nil, fmt.Errorf("failed to decide fraud review: %w", err)
+	}
+
+	return review, nil
+}
+
+// summarize joins the reasons of the findings that rejected a charge
+func summarize(findings []Finding) string {
+	var reasons []string
+	for _, finding := range findings {
+		if finding.Decision == DecisionReject {
+			reasons = append(reasons, finding.Reason)
+		}
+	}
+	return strings.Join(reasons, "; ")
+}
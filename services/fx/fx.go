@@ -0,0 +1,120 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"apis/payments/services/money"
+)
+
+// Exchange rate providers
+const (
+	ProviderECB               = "ecb"
+	ProviderOpenExchangeRates = "openexchangerates"
+)
+
+// ErrUnsupportedCurrency is returned when converting from or to a currency
+// the rate provider does not quote
+var ErrUnsupportedCurrency = errors.New("currency is not supported for conversion")
+
+// ErrRatesUnavailable is returned when rates cannot be fetched and none are cached
+var ErrRatesUnavailable = errors.New("exchange rates are unavailable")
+
+// Rates are exchange rates quoted against one base currency at one time
+type Rates struct {
+	Provider string
+	Base     string
+	Quotes   map[string]*big.Rat // Units of each currency per unit of Base
+	AsOf     time.Time
+}
+
+// Rate returns the rate converting from one currency to another, crossing
+// through the base currency when neither is the base
+func (r *Rates) Rate(from, to string) (*big.Rat, error) {
+	from, to = strings.ToLower(from), strings.ToLower(to)
+
+	fromQuote, err := r.quote(from)
+	if err != nil {
+		return nil, err
+	}
+	toQuote, err := r.quote(to)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Rat).Quo(toQuote, fromQuote), nil
+}
+
+// quote returns the units of a currency per unit of the base
+func (r *Rates) quote(currency string) (*big.Rat, error) {
+	if currency == r.Base {
+		return big.NewRat(1, 1), nil
+	}
+	quote, ok := r.Quotes[currency]
+	if !ok || quote.Sign() <= 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, strings.ToUpper(currency))
+	}
+	return quote, nil
+}
+
+// Conversion is an amount converted to another currency
+type Conversion struct {
+	From        money.Money   `json:"from"`
+	FromDisplay money.Display `json:"from_display"`
+	To          money.Money   `json:"to"`
+	ToDisplay   money.Display `json:"to_display"`
+	Rate        string        `json:"rate"` // Units of the target currency per unit of the source, to 8 places
+	Provider    string        `json:"provider"`
+	AsOf        time.Time     `json:"as_of"` // When the provider published the rate
+}
+
+// Provider fetches the latest exchange rates
+type Provider interface {
+	LatestRates(ctx context.Context) (*Rates, error)
+}
+
+// Config selects the exchange rate provider and how long its rates are cached
+type Config struct {
+	Provider string
+	AppID    string        // OpenExchangeRates app ID
+	CacheTTL time.Duration // How long fetched rates are used before refetching
+}
+
+// LoadConfig loads the exchange rate configuration from environment variables
+func LoadConfig() *Config {
+	config := &Config{
+		Provider: ProviderECB,
+		AppID:    os.Getenv("OPEN_EXCHANGE_RATES_APP_ID"),
+		CacheTTL: time.Hour,
+	}
+
+	if provider := os.Getenv("FX_PROVIDER"); provider != "" {
+		config.Provider = strings.ToLower(provider)
+	}
+	if minutes, err := strconv.Atoi(os.Getenv("FX_CACHE_TTL_MINUTES")); err == nil && minutes > 0 {
+		config.CacheTTL = time.Duration(minutes) * time.Minute
+	}
+
+	return config
+}
+
+// NewProvider creates the configured exchange rate provider
+func NewProvider(config *Config) (Provider, error) {
+	switch config.Provider {
+	case ProviderECB:
+		return NewECBProvider(), nil
+	case ProviderOpenExchangeRates:
+		if config.AppID == "" {
+			return nil, fmt.Errorf("OPEN_EXCHANGE_RATES_APP_ID is required for the %s provider", ProviderOpenExchangeRates)
+		}
+		return NewOpenExchangeRatesProvider(config.AppID), nil
+	default:
+		return nil, fmt.Errorf("unknown exchange rate provider %q", config.Provider)
+	}
+}
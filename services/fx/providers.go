@@ -0,0 +1,150 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	ecbDailyURL            = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+	ecbDateLayout          = "2006-01-02"
+	openExchangeRatesURL   = "https://openexchangerates.org/api/latest.json"
+	providerRequestTimeout = 10 * time.Second
+)
+
+// ECBProvider fetches the euro reference rates the European Central Bank
+// publishes each working day around 16:00 CET. It quotes about 30 currencies.
+type ECBProvider struct {
+	url    string
+	client *http.Client
+}
+
+// NewECBProvider creates an ECB reference rate provider
+func NewECBProvider() *ECBProvider {
+	return &ECBProvider{
+		url:    ecbDailyURL,
+		client: &http.Client{Timeout: providerRequestTimeout},
+	}
+}
+
+// ecbEnvelope is the daily reference rate document
+type ecbEnvelope struct {
+	Cube struct {
+		Cube struct {
+			Time  string `xml:"time,attr"`
+			Rates []struct {
+				Currency string `xml:"currency,attr"`
+				Rate     string `xml:"rate,attr"`
+			} `xml:"Cube"`
+		} `xml:"Cube"`
+	} `xml:"Cube"`
+}
+
+// LatestRates fetches the latest reference rates
+func (p *ECBProvider) LatestRates(ctx context.Context) (*Rates, error) {
+	resp, err := get(ctx, p.client, p.url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var envelope ecbEnvelope
+	if err := xml.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("failed to parse ECB rates: %w", err)
+	}
+
+	asOf, err := time.Parse(ecbDateLayout, envelope.Cube.Cube.Time)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ECB rate date %q: %w", envelope.Cube.Cube.Time, err)
+	}
+
+	rates := &Rates{Provider: ProviderECB, Base: "eur", Quotes: make(map[string]*big.Rat), AsOf: asOf}
+	for _, quote := range envelope.Cube.Cube.Rates {
+		rate, ok := new(big.Rat).SetString(quote.Rate)
+		if !ok {
+			return nil, fmt.Errorf("invalid ECB rate %q for %s", quote.Rate, quote.Currency)
+		}
+		rates.Quotes[strings.ToLower(quote.Currency)] = rate
+	}
+
+	return rates, nil
+}
+
+// OpenExchangeRatesProvider fetches rates from Open Exchange Rates, which
+// quotes about 170 currencies against USD and updates hourly
+type OpenExchangeRatesProvider struct {
+	url    string
+	appID  string
+	client *http.Client
+}
+
+// NewOpenExchangeRatesProvider creates an Open Exchange Rates provider
+func NewOpenExchangeRatesProvider(appID string) *OpenExchangeRatesProvider {
+	return &OpenExchangeRatesProvider{
+		url:    openExchangeRatesURL,
+		appID:  appID,
+		client: &http.Client{Timeout: providerRequestTimeout},
+	}
+}
+
+// LatestRates fetches the latest rates
+func (p *OpenExchangeRatesProvider) LatestRates(ctx context.Context) (*Rates, error) {
+	resp, err := get(ctx, p.client, p.url+"?app_id="+url.QueryEscape(p.appID))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var latest struct {
+		Timestamp int64                  `json:"timestamp"`
+		Base      string                 `json:"base"`
+		Rates     map[string]json.Number `json:"rates"`
+	}
+	decoder := json.NewDecoder(resp.Body)
+	decoder.UseNumber() // Keep the published digits rather than rounding through float64
+	if err := decoder.Decode(&latest); err != nil {
+		return nil, fmt.Errorf("failed to parse Open Exchange Rates response: %w", err)
+	}
+
+	rates := &Rates{
+		Provider: ProviderOpenExchangeRates,
+		Base:     strings.ToLower(latest.Base),
+		Quotes:   make(map[string]*big.Rat, len(latest.Rates)),
+		AsOf:     time.Unix(latest.Timestamp, 0).UTC(),
+	}
+	for currency, number := range latest.Rates {
+		rate, ok := new(big.Rat).SetString(number.String())
+		if !ok {
+			return nil, fmt.Errorf("invalid Open Exchange Rates rate %q for %s", number, currency)
+		}
+		rates.Quotes[strings.ToLower(currency)] = rate
+	}
+
+	return rates, nil
+}
+
+// get fetches a URL, failing on non-2xx responses
+func get(ctx context.Context, client *http.Client, target string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build rate request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch exchange rates: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("exchange rate provider returned status %d", resp.StatusCode)
+	}
+
+	return resp, nil
+}
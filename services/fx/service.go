@@ -0,0 +1,97 @@
+package fx
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"apis/payments/services/money"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Service converts amounts between currencies at the provider's latest
+// rates, caching them for CacheTTL
+type Service struct {
+	provider Provider
+	config   *Config
+	tracer   trace.Tracer
+
+	mu        sync.Mutex
+	rates     *Rates
+	fetchedAt time.Time
+}
+
+// NewService creates a new currency conversion service
+func NewService(provider Provider, config *Config) *Service {
+	if config == nil {
+		config = LoadConfig()
+	}
+
+	return &Service{
+		provider: provider,
+		config:   config,
+		tracer:   otel.Tracer("payments.fx"),
+	}
+}
+
+// Convert converts an amount in minor units to another currency
+func (s *Service) Convert(ctx context.Context, amount money.Money, currency string) (*Conversion, error) {
+	ctx, span := s.tracer.Start(ctx, "Convert")
+	defer span.End()
+
+	if !money.ValidCurrency(amount.Currency) || !money.ValidCurrency(currency) {
+		return nil, money.ErrInvalidCurrency
+	}
+	amount = money.New(amount.Amount, amount.Currency)
+	currency = strings.ToLower(currency)
+
+	rates, err := s.latestRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rate, err := rates.Rate(amount.Currency, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	converted := amount.Convert(currency, rate)
+	return &Conversion{
+		From:        amount,
+		FromDisplay: amount.Display(),
+		To:          converted,
+		ToDisplay:   converted.Display(),
+		Rate:        rate.FloatString(8),
+		Provider:    rates.Provider,
+		AsOf:        rates.AsOf,
+	}, nil
+}
+
+// latestRates returns the cached rates, fetching them when they have expired.
+// Expired rates are still used if fetching fails, since the provider may be
+// briefly unavailable.
+func (s *Service) latestRates(ctx context.Context) (*Rates, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.rates != nil && time.Since(s.fetchedAt) < s.config.CacheTTL {
+		return s.rates, nil
+	}
+
+	rates, err := s.provider.LatestRates(ctx)
+	if err != nil {
+		if s.rates != nil {
+			log.Printf("Failed to refresh exchange rates, using rates fetched at %s: %v", s.fetchedAt.Format(time.RFC3339), err)
+			return s.rates, nil
+		}
+		return nil, fmt.Errorf("%w: %v", ErrRatesUnavailable, err)
+	}
+
+	s.rates = rates
+	s.fetchedAt = time.Now()
+	return rates, nil
+}
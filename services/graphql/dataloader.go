@@ -0,0 +1,135 @@
+package graphql
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// BatchFunc fetches the values of keys, returning them in the order of the
+// keys. errs holds an error per key, or is nil when every key was fetched.
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) (values []V, errs []error)
+
+// PerKey makes a batch function of a lookup of one key, for sources without
+// batch lookups such as the provider's API. Keys are fetched up to
+// concurrency at a time.
+func PerKey[K comparable, V any](fetch func(ctx context.Context, key K) (V, error), concurrency int) BatchFunc[K, V] {
+	return func(ctx context.Context, keys []K) ([]V, []error) {
+		values := make([]V, len(keys))
+		errs := make([]error, len(keys))
+
+		slots := make(chan struct{}, max(1, concurrency))
+		var wg sync.WaitGroup
+		for i, key := range keys {
+			wg.Add(1)
+			slots <- struct{}{}
+			go func() {
+				defer wg.Done()
+				defer func() { <-slots }()
+				values[i], errs[i] = fetch(ctx, key)
+			}()
+		}
+		wg.Wait()
+
+		return values, errs
+	}
+}
+
+// Loader collects the keys loaded within a short wait and fetches them in
+// one batch, caching every value for the life of the loader. Loaders are
+// created per request, so resolvers of different requests never share
+// values.
+type Loader[K comparable, V any] struct {
+	fetch BatchFunc[K, V]
+	wait  time.Duration
+
+	mu      sync.Mutex
+	results map[K]*loaderResult[V]
+	pending []K
+}
+
+type loaderResult[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// NewLoader creates a loader that waits for keys before fetching them
+func NewLoader[K comparable, V any](fetch BatchFunc[K, V], wait time.Duration) *Loader[K, V] {
+	return &Loader[K, V]{
+		fetch:   fetch,
+		wait:    wait,
+		results: make(map[K]*loaderResult[V]),
+	}
+}
+
+// Load returns the value of a key, fetching it with the other keys loaded
+// meanwhile unless it has been loaded before
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	result, ok := l.results[key]
+	if !ok {
+		result = &loaderResult[V]{done: make(chan struct{})}
+		l.results[key] = result
+		l.pending = append(l.pending, key)
+		// The first key of a batch schedules its fetch
+		if len(l.pending) == 1 {
+			time.AfterFunc(l.wait, func() { l.dispatch(ctx) })
+		}
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-result.done:
+		return result.value, result.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// LoadMany returns the values of several keys, fetched in one batch
+func (l *Loader[K, V]) LoadMany(ctx context.Context, keys []K) ([]V, error) {
+	values := make([]V, len(keys))
+	errs := make([]error, len(keys))
+
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			values[i], errs[i] = l.Load(ctx, key)
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// dispatch fetches the pending batch
+func (l *Loader[K, V]) dispatch(ctx context.Context) {
+	l.mu.Lock()
+	keys := l.pending
+	l.pending = nil
+	results := make([]*loaderResult[V], len(keys))
+	for i, key := range keys {
+		results[i] = l.results[key]
+	}
+	l.mu.Unlock()
+
+	values, errs := l.fetch(ctx, keys)
+	for i, result := range results {
+		if i < len(values) {
+			result.value = values[i]
+		}
+		if i < len(errs) {
+			result.err = errs[i]
+		}
+		close(result.done)
+	}
+}
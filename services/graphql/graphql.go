@@ -0,0 +1,53 @@
+package graphql
+
+import (
+	"errors"
+	"time"
+
+	"apis/payments/services/config"
+)
+
+var (
+	// ErrSyntax is returned for documents that cannot be parsed
+	ErrSyntax = errors.New("graphql syntax error")
+	// ErrInvalidQuery is returned for queries that don't match the schema
+	ErrInvalidQuery = errors.New("invalid graphql query")
+	// ErrOperationNotSupported is returned for mutations and subscriptions
+	ErrOperationNotSupported = errors.New("only queries are supported")
+)
+
+// Request is a GraphQL request body
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// Response is a GraphQL response body. Data is absent when the request
+// could not be executed at all.
+type Response struct {
+	Data   any      `json:"data,omitempty"`
+	Errors []*Error `json:"errors,omitempty"`
+}
+
+// Error is an error of a request, or of the field at Path
+type Error struct {
+	Message string `json:"message"`
+	Path    []any  `json:"path,omitempty"`
+}
+
+// Config limits the queries a schema executes
+type Config struct {
+	MaxDepth          int           // Deepest nesting of fields a query may select
+	LoaderWait        time.Duration // How long loaders collect keys before fetching a batch
+	LoaderConcurrency int           // Keys a loader fetches at once when the source has no batch lookup
+}
+
+// LoadConfig loads the GraphQL configuration from environment variables
+func LoadConfig() *Config {
+	return &Config{
+		MaxDepth:          config.Int("GRAPHQL_MAX_DEPTH", 6),
+		LoaderWait:        time.Duration(config.Int("GRAPHQL_LOADER_WAIT_MS", 2)) * time.Millisecond,
+		LoaderConcurrency: config.Int("GRAPHQL_LOADER_CONCURRENCY", 8),
+	}
+}
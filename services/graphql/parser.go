@@ -0,0 +1,641 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// document is a parsed request document
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+// operation is a query, mutation or subscription of a document
+type operation struct {
+	kind       string
+	name       string
+	variables  []*variableDefinition
+	selections []*selection
+}
+
+// variableDefinition declares a variable an operation takes
+type variableDefinition struct {
+	name         string
+	required     bool
+	defaultValue any
+}
+
+// fragment is a named fragment of a document
+type fragment struct {
+	name          string
+	typeCondition string
+	selections    []*selection
+}
+
+// selection is a field, a fragment spread (fragment set) or an inline
+// fragment (inline set)
+type selection struct {
+	alias      string
+	name       string
+	arguments  map[string]any
+	directives []*directive
+	selections []*selection
+
+	fragment      string
+	inline        bool
+	typeCondition string
+}
+
+// responseKey is the key a field's value is returned under
+func (s *selection) responseKey() string {
+	if s.alias != "" {
+		return s.alias
+	}
+	return s.name
+}
+
+// directive is a directive applied to a selection, such as @include
+type directive struct {
+	name      string
+	arguments map[string]any
+}
+
+// variable is a reference to a variable within an argument value
+type variable string
+
+// token kinds
+const (
+	tokenEOF = iota
+	tokenPunctuator
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind  int
+	value string
+	pos   int
+}
+
+// parser is a recursive descent parser for executable documents
+type parser struct {
+	source string
+	pos    int
+	token  token
+}
+
+// parse parses a request document
+func parse(source string) (*document, error) {
+	p := &parser{source: source}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	doc := &document{fragments: make(map[string]*fragment)}
+	for p.token.kind != tokenEOF {
+		switch {
+		case p.peek(tokenPunctuator, "{"):
+			selections, err := p.parseSelectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, &operation{kind: "query", selections: selections})
+		case p.peek(tokenName, "fragment"):
+			frag, err := p.parseFragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := doc.fragments[frag.name]; ok {
+				return nil, fmt.Errorf("%w: fragment %q is defined twice", ErrSyntax, frag.name)
+			}
+			doc.fragments[frag.name] = frag
+		case p.peek(tokenName, "query"), p.peek(tokenName, "mutation"), p.peek(tokenName, "subscription"):
+			op, err := p.parseOperation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		default:
+			return nil, p.unexpected()
+		}
+	}
+
+	if len(doc.operations) == 0 {
+		return nil, fmt.Errorf("%w: document has no operations", ErrSyntax)
+	}
+	return doc, nil
+}
+
+func (p *parser) parseOperation() (*operation, error) {
+	op := &operation{kind: p.token.value}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	if p.token.kind == tokenName {
+		op.name = p.token.value
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.peek(tokenPunctuator, "(") {
+		variables, err := p.parseVariableDefinitions()
+		if err != nil {
+			return nil, err
+		}
+		op.variables = variables
+	}
+
+	if _, err := p.parseDirectives(); err != nil {
+		return nil, err
+	}
+
+	selections, err := p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.selections = selections
+	return op, nil
+}
+
+func (p *parser) parseVariableDefinitions() ([]*variableDefinition, error) {
+	if err := p.expect(tokenPunctuator, "("); err != nil {
+		return nil, err
+	}
+
+	var definitions []*variableDefinition
+	for !p.peek(tokenPunctuator, ")") {
+		if err := p.expect(tokenPunctuator, "$"); err != nil {
+			return nil, err
+		}
+		name, err := p.parseName()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokenPunctuator, ":"); err != nil {
+			return nil, err
+		}
+		required, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+
+		definition := &variableDefinition{name: name, required: required}
+		if p.peek(tokenPunctuator, "=") {
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			if definition.defaultValue, err = p.parseValue(true); err != nil {
+				return nil, err
+			}
+		}
+		definitions = append(definitions, definition)
+	}
+
+	return definitions, p.advance()
+}
+
+// parseType skips a variable's type, returning whether it is non-null.
+// Values are checked by the resolvers that read them.
+func (p *parser) parseType() (bool, error) {
+	if p.peek(tokenPunctuator, "[") {
+		if err := p.advance(); err != nil {
+			return false, err
+		}
+		if _, err := p.parseType(); err != nil {
+			return false, err
+		}
+		if err := p.expect(tokenPunctuator, "]"); err != nil {
+			return false, err
+		}
+	} else if _, err := p.parseName(); err != nil {
+		return false, err
+	}
+
+	if p.peek(tokenPunctuator, "!") {
+		return true, p.advance()
+	}
+	return false, nil
+}
+
+func (p *parser) parseFragment() (*fragment, error) {
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	name, err := p.parseName()
+	if err != nil {
+		return nil, err
+	}
+	if name == "on" {
+		return nil, fmt.Errorf("%w: fragment cannot be named \"on\"", ErrSyntax)
+	}
+	if err := p.expect(tokenName, "on"); err != nil {
+		return nil, err
+	}
+	typeCondition, err := p.parseName()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.parseDirectives(); err != nil {
+		return nil, err
+	}
+
+	selections, err := p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+	return &fragment{name: name, typeCondition: typeCondition, selections: selections}, nil
+}
+
+func (p *parser) parseSelectionSet() ([]*selection, error) {
+	if err := p.expect(tokenPunctuator, "{"); err != nil {
+		return nil, err
+	}
+
+	var selections []*selection
+	for !p.peek(tokenPunctuator, "}") {
+		sel, err := p.parseSelection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, sel)
+	}
+	if len(selections) == 0 {
+		return nil, fmt.Errorf("%w: empty selection set at %d", ErrSyntax, p.token.pos)
+	}
+
+	return selections, p.advance()
+}
+
+func (p *parser) parseSelection() (*selection, error) {
+	if p.peek(tokenPunctuator, "...") {
+		return p.parseFragmentSelection()
+	}
+
+	name, err := p.parseName()
+	if err != nil {
+		return nil, err
+	}
+	sel := &selection{name: name}
+	if p.peek(tokenPunctuator, ":") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		sel.alias = name
+		if sel.name, err = p.parseName(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.peek(tokenPunctuator, "(") {
+		if sel.arguments, err = p.parseArguments(false); err != nil {
+			return nil, err
+		}
+	}
+	if sel.directives, err = p.parseDirectives(); err != nil {
+		return nil, err
+	}
+	if p.peek(tokenPunctuator, "{") {
+		if sel.selections, err = p.parseSelectionSet(); err != nil {
+			return nil, err
+		}
+	}
+
+	return sel, nil
+}
+
+func (p *parser) parseFragmentSelection() (*selection, error) {
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	sel := &selection{}
+	var err error
+	if p.token.kind == tokenName && p.token.value != "on" {
+		if sel.fragment, err = p.parseName(); err != nil {
+			return nil, err
+		}
+		sel.directives, err = p.parseDirectives()
+		return sel, err
+	}
+
+	sel.inline = true
+	if p.peek(tokenName, "on") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if sel.typeCondition, err = p.parseName(); err != nil {
+			return nil, err
+		}
+	}
+	if sel.directives, err = p.parseDirectives(); err != nil {
+		return nil, err
+	}
+	if sel.selections, err = p.parseSelectionSet(); err != nil {
+		return nil, err
+	}
+
+	return sel, nil
+}
+
+func (p *parser) parseDirectives() ([]*directive, error) {
+	var directives []*directive
+	for p.peek(tokenPunctuator, "@") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.parseName()
+		if err != nil {
+			return nil, err
+		}
+
+		dir := &directive{name: name}
+		if p.peek(tokenPunctuator, "(") {
+			if dir.arguments, err = p.parseArguments(false); err != nil {
+				return nil, err
+			}
+		}
+		directives = append(directives, dir)
+	}
+
+	return directives, nil
+}
+
+func (p *parser) parseArguments(constant bool) (map[string]any, error) {
+	if err := p.expect(tokenPunctuator, "("); err != nil {
+		return nil, err
+	}
+
+	arguments := make(map[string]any)
+	for !p.peek(tokenPunctuator, ")") {
+		name, err := p.parseName()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokenPunctuator, ":"); err != nil {
+			return nil, err
+		}
+		if arguments[name], err = p.parseValue(constant); err != nil {
+			return nil, err
+		}
+	}
+
+	return arguments, p.advance()
+}
+
+// parseValue parses an input value. Constant values, such as variable
+// defaults, cannot reference variables.
+func (p *parser) parseValue(constant bool) (any, error) {
+	tok := p.token
+	switch {
+	case tok.kind == tokenPunctuator && tok.value == "$" && !constant:
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.parseName()
+		return variable(name), err
+	case tok.kind == tokenPunctuator && tok.value == "[":
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		list := []any{}
+		for !p.peek(tokenPunctuator, "]") {
+			item, err := p.parseValue(constant)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+		return list, p.advance()
+	case tok.kind == tokenPunctuator && tok.value == "{":
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		object := make(map[string]any)
+		for !p.peek(tokenPunctuator, "}") {
+			name, err := p.parseName()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(tokenPunctuator, ":"); err != nil {
+				return nil, err
+			}
+			if object[name], err = p.parseValue(constant); err != nil {
+				return nil, err
+			}
+		}
+		return object, p.advance()
+	case tok.kind == tokenInt:
+		value, err := strconv.ParseInt(tok.value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid integer %s", ErrSyntax, tok.value)
+		}
+		return value, p.advance()
+	case tok.kind == tokenFloat:
+		value, err := strconv.ParseFloat(tok.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid float %s", ErrSyntax, tok.value)
+		}
+		return value, p.advance()
+	case tok.kind == tokenString:
+		return tok.value, p.advance()
+	case tok.kind == tokenName:
+		// Enum values are passed to resolvers as their names
+		var value any = tok.value
+		switch tok.value {
+		case "true":
+			value = true
+		case "false":
+			value = false
+		case "null":
+			value = nil
+		}
+		return value, p.advance()
+	}
+
+	return nil, p.unexpected()
+}
+
+func (p *parser) parseName() (string, error) {
+	if p.token.kind != tokenName {
+		return "", p.unexpected()
+	}
+	name := p.token.value
+	return name, p.advance()
+}
+
+// peek reports whether the current token is of a kind and value
+func (p *parser) peek(kind int, value string) bool {
+	return p.token.kind == kind && p.token.value == value
+}
+
+// expect consumes the current token if it is of a kind and value
+func (p *parser) expect(kind int, value string) error {
+	if !p.peek(kind, value) {
+		return p.unexpected()
+	}
+	return p.advance()
+}
+
+func (p *parser) unexpected() error {
+	if p.token.kind == tokenEOF {
+		return fmt.Errorf("%w: unexpected end of document", ErrSyntax)
+	}
+	return fmt.Errorf("%w: unexpected %q at %d", ErrSyntax, p.token.value, p.token.pos)
+}
+
+// advance reads the next token, skipping whitespace, commas and comments
+func (p *parser) advance() error {
+	for p.pos < len(p.source) {
+		c := p.source[p.pos]
+		if c == '#' {
+			for p.pos < len(p.source) && p.source[p.pos] != '\n' && p.source[p.pos] != '\r' {
+				p.pos++
+			}
+			continue
+		}
+		if c != ' ' && c != '\t' && c != '\n' && c != '\r' && c != ',' {
+			break
+		}
+		p.pos++
+	}
+
+	start := p.pos
+	if p.pos >= len(p.source) {
+		p.token = token{kind: tokenEOF, pos: start}
+		return nil
+	}
+
+	c := p.source[p.pos]
+	switch {
+	case strings.HasPrefix(p.source[p.pos:], "..."):
+		p.pos += 3
+		p.token = token{kind: tokenPunctuator, value: "...", pos: start}
+	case strings.IndexByte("!$():=@[]{}|", c) >= 0:
+		p.pos++
+		p.token = token{kind: tokenPunctuator, value: string(c), pos: start}
+	case c == '_' || isLetter(c):
+		for p.pos < len(p.source) && (p.source[p.pos] == '_' || isLetter(p.source[p.pos]) || isDigit(p.source[p.pos])) {
+			p.pos++
+		}
+		p.token = token{kind: tokenName, value: p.source[start:p.pos], pos: start}
+	case c == '-' || isDigit(c):
+		return p.readNumber()
+	case c == '"':
+		return p.readString()
+	default:
+		return fmt.Errorf("%w: unexpected character %q at %d", ErrSyntax, c, start)
+	}
+
+	return nil
+}
+
+func (p *parser) readNumber() error {
+	start := p.pos
+	kind := tokenInt
+	if p.source[p.pos] == '-' {
+		p.pos++
+	}
+	digits := func() int {
+		from := p.pos
+		for p.pos < len(p.source) && isDigit(p.source[p.pos]) {
+			p.pos++
+		}
+		return p.pos - from
+	}
+
+	if digits() == 0 {
+		return fmt.Errorf("%w: invalid number at %d", ErrSyntax, start)
+	}
+	if p.pos < len(p.source) && p.source[p.pos] == '.' {
+		kind = tokenFloat
+		p.pos++
+		if digits() == 0 {
+			return fmt.Errorf("%w: invalid number at %d", ErrSyntax, start)
+		}
+	}
+	if p.pos < len(p.source) && (p.source[p.pos] == 'e' || p.source[p.pos] == 'E') {
+		kind = tokenFloat
+		p.pos++
+		if p.pos < len(p.source) && (p.source[p.pos] == '+' || p.source[p.pos] == '-') {
+			p.pos++
+		}
+		if digits() == 0 {
+			return fmt.Errorf("%w: invalid number at %d", ErrSyntax, start)
+		}
+	}
+
+	p.token = token{kind: kind, value: p.source[start:p.pos], pos: start}
+	return nil
+}
+
+func (p *parser) readString() error {
+	start := p.pos
+	p.pos++
+
+	var value strings.Builder
+	for p.pos < len(p.source) {
+		c := p.source[p.pos]
+		switch {
+		case c == '"':
+			p.pos++
+			p.token = token{kind: tokenString, value: value.String(), pos: start}
+			return nil
+		case c == '\n' || c == '\r':
+			return fmt.Errorf("%w: unterminated string at %d", ErrSyntax, start)
+		case c == '\\':
+			if p.pos+1 >= len(p.source) {
+				return fmt.Errorf("%w: unterminated string at %d", ErrSyntax, start)
+			}
+			escape := p.source[p.pos+1]
+			p.pos += 2
+			switch escape {
+			case '"', '\\', '/':
+				value.WriteByte(escape)
+			case 'b':
+				value.WriteByte('\b')
+			case 'f':
+				value.WriteByte('\f')
+			case 'n':
+				value.WriteByte('\n')
+			case 'r':
+				value.WriteByte('\r')
+			case 't':
+				value.WriteByte('\t')
+			case 'u':
+				if p.pos+4 > len(p.source) {
+					return fmt.Errorf("%w: invalid unicode escape at %d", ErrSyntax, p.pos)
+				}
+				code, err := strconv.ParseUint(p.source[p.pos:p.pos+4], 16, 32)
+				if err != nil {
+					return fmt.Errorf("%w: invalid unicode escape at %d", ErrSyntax, p.pos)
+				}
+				value.WriteRune(rune(code))
+				p.pos += 4
+			default:
+				return fmt.Errorf("%w: invalid escape \\%c at %d", ErrSyntax, escape, p.pos-2)
+			}
+		default:
+			r, size := utf8.DecodeRuneInString(p.source[p.pos:])
+			value.WriteRune(r)
+			p.pos += size
+		}
+	}
+
+	return fmt.Errorf("%w: unterminated string at %d", ErrSyntax, start)
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
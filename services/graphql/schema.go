@@ -0,0 +1,530 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// ResolveFunc resolves a field of a source value, the value of the parent
+// field or nil for root fields
+type ResolveFunc func(ctx context.Context, source any, args Args) (any, error)
+
+// Object is an object type of a schema
+type Object struct {
+	Name   string
+	Fields map[string]*Field
+}
+
+// Field is a field of an object type
+type Field struct {
+	// Type is the object type of the value, or of each element when the
+	// value is a slice. Fields without a type return scalars, and values
+	// such as metadata as JSON.
+	Type *Object
+	// Resolve resolves the field. Fields without a resolver read the
+	// source's struct field whose JSON name is the field name in
+	// snake_case, e.g. expMonth reads `json:"exp_month"`.
+	Resolve ResolveFunc
+}
+
+// Scalars returns fields read from the source by name
+func Scalars(names ...string) map[string]*Field {
+	fields := make(map[string]*Field, len(names))
+	for _, name := range names {
+		fields[name] = &Field{}
+	}
+	return fields
+}
+
+// Args are the arguments of a field, with variables substituted
+type Args map[string]any
+
+// String returns a string argument, or "" when it is not set
+func (a Args) String(name string) string {
+	value, _ := a[name].(string)
+	return value
+}
+
+// Int returns an integer argument, or defaultValue when it is not set.
+// Variables decoded from JSON arrive as floats.
+func (a Args) Int(name string, defaultValue int) int {
+	switch value := a[name].(type) {
+	case int64:
+		return int(value)
+	case float64:
+		return int(value)
+	case int:
+		return value
+	}
+	return defaultValue
+}
+
+// Bool returns a boolean argument, or false when it is not set
+func (a Args) Bool(name string) bool {
+	value, _ := a[name].(bool)
+	return value
+}
+
+// Schema executes queries against its root query type
+type Schema struct {
+	query  *Object
+	config *Config
+}
+
+// NewSchema creates a schema whose queries start at the given root type
+func NewSchema(query *Object, config *Config) *Schema {
+	if config == nil {
+		config = LoadConfig()
+	}
+
+	return &Schema{query: query, config: config}
+}
+
+// Execute runs a request's query. Errors resolving a field null it and are
+// reported with its path, alongside the rest of the data.
+func (s *Schema) Execute(ctx context.Context, request Request) *Response {
+	doc, err := parse(request.Query)
+	if err != nil {
+		return failed(err)
+	}
+
+	op, err := doc.operation(request.OperationName)
+	if err != nil {
+		return failed(err)
+	}
+	if op.kind != "query" {
+		return failed(fmt.Errorf("%w: %s", ErrOperationNotSupported, op.kind))
+	}
+
+	variables, err := op.coerceVariables(request.Variables)
+	if err != nil {
+		return failed(err)
+	}
+
+	e := &executor{fragments: doc.fragments, variables: variables}
+	if err := e.validate(s.query, op.selections, 1, s.config.MaxDepth); err != nil {
+		return failed(err)
+	}
+
+	data := e.object(ctx, s.query, nil, op.selections, nil)
+	return &Response{Data: data, Errors: e.errors}
+}
+
+// failed is the response to a request that could not be executed
+func failed(err error) *Response {
+	return &Response{Errors: []*Error{{Message: err.Error()}}}
+}
+
+// operation returns the named operation, or the only one when name is empty
+func (d *document) operation(name string) (*operation, error) {
+	if name == "" {
+		if len(d.operations) > 1 {
+			return nil, fmt.Errorf("%w: operationName is required for documents with several operations", ErrInvalidQuery)
+		}
+		return d.operations[0], nil
+	}
+
+	for _, op := range d.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: unknown operation %q", ErrInvalidQuery, name)
+}
+
+// coerceVariables applies an operation's variable defaults to the values
+// sent, requiring non-null variables
+func (o *operation) coerceVariables(values map[string]any) (map[string]any, error) {
+	variables := make(map[string]any, len(o.variables))
+	for _, definition := range o.variables {
+		value, ok := values[definition.name]
+		if !ok {
+			value = definition.defaultValue
+		}
+		if value == nil && definition.required {
+			return nil, fmt.Errorf("%w: variable $%s is required", ErrInvalidQuery, definition.name)
+		}
+		variables[definition.name] = value
+	}
+	return variables, nil
+}
+
+// executor executes one operation
+type executor struct {
+	fragments map[string]*fragment
+	variables map[string]any
+
+	mu     sync.Mutex
+	errors []*Error
+}
+
+// validate checks that every field selected exists on its type, selects
+// subfields exactly when it returns an object, and is within the depth limit
+func (e *executor) validate(obj *Object, selections []*selection, depth, maxDepth int) error {
+	if maxDepth > 0 && depth > maxDepth {
+		return fmt.Errorf("%w: query is nested deeper than %d", ErrInvalidQuery, maxDepth)
+	}
+
+	fields, err := e.collect(obj, selections)
+	if err != nil {
+		return err
+	}
+	for _, sel := range fields {
+		if sel.name == "__typename" {
+			if sel.selections != nil {
+				return fmt.Errorf("%w: field \"__typename\" cannot select subfields", ErrInvalidQuery)
+			}
+			continue
+		}
+
+		field, ok := obj.Fields[sel.name]
+		if !ok {
+			return fmt.Errorf("%w: type %s has no field %q", ErrInvalidQuery, obj.Name, sel.name)
+		}
+		switch {
+		case field.Type == nil && sel.selections != nil:
+			return fmt.Errorf("%w: field %q of type %s cannot select subfields", ErrInvalidQuery, sel.name, obj.Name)
+		case field.Type != nil && sel.selections == nil:
+			return fmt.Errorf("%w: field %q of type %s must select subfields", ErrInvalidQuery, sel.name, obj.Name)
+		case field.Type != nil:
+			if err := e.validate(field.Type, sel.selections, depth+1, maxDepth); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// collect flattens fragments into the fields selected on an object type, in
+// order, merging the subfields of fields selected under the same key and
+// dropping fields skipped by @skip or @include
+func (e *executor) collect(obj *Object, selections []*selection) ([]*selection, error) {
+	var fields []*selection
+	byKey := make(map[string]int)
+
+	var walk func(selections []*selection, visiting map[string]bool) error
+	walk = func(selections []*selection, visiting map[string]bool) error {
+		for _, sel := range selections {
+			included, err := e.included(sel)
+			if err != nil {
+				return err
+			}
+			if !included {
+				continue
+			}
+
+			switch {
+			case sel.fragment != "":
+				frag, ok := e.fragments[sel.fragment]
+				if !ok {
+					return fmt.Errorf("%w: unknown fragment %q", ErrInvalidQuery, sel.fragment)
+				}
+				if visiting[frag.name] {
+					return fmt.Errorf("%w: fragment %q spreads itself", ErrInvalidQuery, frag.name)
+				}
+				if frag.typeCondition != obj.Name {
+					continue
+				}
+				visiting[frag.name] = true
+				if err := walk(frag.selections, visiting); err != nil {
+					return err
+				}
+				delete(visiting, frag.name)
+			case sel.inline:
+				if sel.typeCondition != "" && sel.typeCondition != obj.Name {
+					continue
+				}
+				if err := walk(sel.selections, visiting); err != nil {
+					return err
+				}
+			default:
+				key := sel.responseKey()
+				if i, ok := byKey[key]; ok {
+					if fields[i].name != sel.name {
+						return fmt.Errorf("%w: fields %q and %q are both returned as %q", ErrInvalidQuery, fields[i].name, sel.name, key)
+					}
+					merged := *fields[i]
+					merged.selections = append(append([]*selection(nil), merged.selections...), sel.selections...)
+					fields[i] = &merged
+					continue
+				}
+				byKey[key] = len(fields)
+				fields = append(fields, sel)
+			}
+		}
+		return nil
+	}
+
+	if err := walk(selections, make(map[string]bool)); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// included applies a selection's @skip and @include directives
+func (e *executor) included(sel *selection) (bool, error) {
+	for _, dir := range sel.directives {
+		switch dir.name {
+		case "skip", "include":
+			condition, ok := e.value(dir.arguments["if"]).(bool)
+			if !ok {
+				return false, fmt.Errorf("%w: @%s needs a boolean \"if\" argument", ErrInvalidQuery, dir.name)
+			}
+			if condition == (dir.name == "skip") {
+				return false, nil
+			}
+		default:
+			return false, fmt.Errorf("%w: unknown directive @%s", ErrInvalidQuery, dir.name)
+		}
+	}
+	return true, nil
+}
+
+// value substitutes variables into an argument value
+func (e *executor) value(value any) any {
+	switch value := value.(type) {
+	case variable:
+		return e.variables[string(value)]
+	case []any:
+		list := make([]any, len(value))
+		for i, item := range value {
+			list[i] = e.value(item)
+		}
+		return list
+	case map[string]any:
+		object := make(map[string]any, len(value))
+		for name, item := range value {
+			object[name] = e.value(item)
+		}
+		return object
+	}
+	return value
+}
+
+// object resolves the fields selected on a source value of an object type.
+// Fields with resolvers are resolved concurrently, so the lookups of
+// sibling fields reach the loaders together.
+func (e *executor) object(ctx context.Context, obj *Object, source any, selections []*selection, path []any) *orderedObject {
+	fields, _ := e.collect(obj, selections) // validated already
+	result := &orderedObject{keys: make([]string, len(fields)), values: make([]any, len(fields))}
+
+	var wg sync.WaitGroup
+	for i, sel := range fields {
+		result.keys[i] = sel.responseKey()
+		fieldPath := append(append([]any(nil), path...), sel.responseKey())
+
+		if sel.name == "__typename" {
+			result.values[i] = obj.Name
+			continue
+		}
+
+		field := obj.Fields[sel.name]
+		if field.Resolve == nil {
+			result.values[i] = e.complete(ctx, field, sel, readField(source, sel.name), fieldPath)
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result.values[i] = e.resolve(ctx, field, sel, source, fieldPath)
+		}()
+	}
+	wg.Wait()
+
+	return result
+}
+
+// resolve runs a field's resolver and completes its value, recording a
+// failed or panicking resolver as an error of the field
+func (e *executor) resolve(ctx context.Context, field *Field, sel *selection, source any, path []any) (value any) {
+	defer func() {
+		if r := recover(); r != nil {
+			e.fail(path, fmt.Errorf("resolver panicked: %v", r))
+			value = nil
+		}
+	}()
+
+	args := make(Args, len(sel.arguments))
+	for name, argument := range sel.arguments {
+		args[name] = e.value(argument)
+	}
+
+	resolved, err := field.Resolve(ctx, source, args)
+	if err != nil {
+		e.fail(path, err)
+		return nil
+	}
+	return e.complete(ctx, field, sel, resolved, path)
+}
+
+// complete resolves the subfields of an object value, or of each element
+// of a list of objects, concurrently
+func (e *executor) complete(ctx context.Context, field *Field, sel *selection, value any, path []any) any {
+	if field.Type == nil || isNil(value) {
+		return value
+	}
+
+	list := reflect.ValueOf(value)
+	if list.Kind() != reflect.Slice {
+		return e.object(ctx, field.Type, value, sel.selections, path)
+	}
+
+	items := make([]any, list.Len())
+	var wg sync.WaitGroup
+	for i := range items {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			itemPath := append(append([]any(nil), path...), i)
+			if item := list.Index(i).Interface(); !isNil(item) {
+				items[i] = e.object(ctx, field.Type, item, sel.selections, itemPath)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return items
+}
+
+func (e *executor) fail(path []any, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.errors = append(e.errors, &Error{Message: err.Error(), Path: path})
+}
+
+// isNil reports whether a value is nil or a nil pointer or map
+func isNil(value any) bool {
+	if value == nil {
+		return true
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+// fieldIndexes caches, per struct type, the index of each field by JSON name
+var fieldIndexes sync.Map
+
+// readField reads the struct field or map entry of a source whose JSON name
+// is the snake_case form of a GraphQL field name. Fields of embedded
+// structs are read too.
+func readField(source any, name string) any {
+	v := reflect.ValueOf(source)
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	key := snakeCase(name)
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		entry := v.MapIndex(reflect.ValueOf(key).Convert(v.Type().Key()))
+		if !entry.IsValid() {
+			return nil
+		}
+		return entry.Interface()
+	case reflect.Struct:
+		index, ok := jsonFields(v.Type())[key]
+		if !ok {
+			return nil
+		}
+		field, err := v.FieldByIndexErr(index)
+		if err != nil {
+			// Through a nil embedded pointer
+			return nil
+		}
+		return field.Interface()
+	}
+	return nil
+}
+
+// jsonFields indexes the exported fields of a struct type, and those of its
+// embedded structs, by JSON name
+func jsonFields(t reflect.Type) map[string][]int {
+	if cached, ok := fieldIndexes.Load(t); ok {
+		return cached.(map[string][]int)
+	}
+
+	indexes := make(map[string][]int)
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("json")
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "-" || (name == "" && field.Anonymous) {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if _, ok := indexes[name]; !ok || len(field.Index) < len(indexes[name]) {
+			indexes[name] = field.Index
+		}
+	}
+
+	fieldIndexes.Store(t, indexes)
+	return indexes
+}
+
+// snakeCase converts a camelCase field name to snake_case
+func snakeCase(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// orderedObject is an object of a response, whose keys are returned in the
+// order they were selected
+type orderedObject struct {
+	keys   []string
+	values []any
+}
+
+// MarshalJSON writes the object's keys in order
+func (o *orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(o.values[i])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,240 @@
+package grpcserver
+
+import (
+	"fmt"
+	"time"
+
+	paymentsv1 "apis/payments/proto/payments/v1"
+	"apis/payments/services"
+	"apis/payments/services/customerbalance"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// convertCustomer converts a gateway customer to its message
+func convertCustomer(customer *services.Customer) *paymentsv1.Customer {
+	return &paymentsv1.Customer{
+		Id:         customer.ID,
+		Email:      customer.Email,
+		Name:       customer.Name,
+		Phone:      customer.Phone,
+		Address:    convertAddress(customer.Address),
+		Metadata:   convertMetadata(customer.Metadata),
+		CreatedAt:  convertTime(customer.CreatedAt),
+		UpdatedAt:  convertTime(customer.UpdatedAt),
+		ProviderId: customer.ProviderID,
+		Provider:   customer.Provider,
+	}
+}
+
+// convertPaymentMethod converts a gateway payment method to its message
+func convertPaymentMethod(method *services.PaymentMethod) *paymentsv1.PaymentMethod {
+	converted := &paymentsv1.PaymentMethod{
+		Id:         method.ID,
+		CustomerId: method.CustomerID,
+		Type:       method.Type,
+		Metadata:   convertMetadata(method.Metadata),
+		CreatedAt:  convertTime(method.CreatedAt),
+		ProviderId: method.ProviderID,
+		Provider:   method.Provider,
+	}
+	if card := method.Card; card != nil {
+		converted.Card = &paymentsv1.Card{
+			Brand:       card.Brand,
+			Last4:       card.Last4,
+			ExpMonth:    int32(card.ExpMonth),
+			ExpYear:     int32(card.ExpYear),
+			Fingerprint: card.Fingerprint,
+			Country:     card.Country,
+		}
+	}
+	if account := method.BankAccount; account != nil {
+		converted.BankAccount = &paymentsv1.BankAccount{
+			BankName:      account.BankName,
+			Last4:         account.Last4,
+			RoutingNumber: account.RoutingNumber,
+			AccountType:   account.AccountType,
+			Country:       account.Country,
+		}
+	}
+
+	return converted
+}
+
+// convertCharge converts a gateway charge to its message
+func convertCharge(charge *services.Charge) *paymentsv1.Charge {
+	return &paymentsv1.Charge{
+		Id:              charge.ID,
+		Amount:          charge.Amount,
+		Currency:        charge.Currency,
+		CustomerId:      charge.CustomerID,
+		PaymentMethodId: charge.PaymentMethodID,
+		Status:          charge.Status,
+		Description:     charge.Description,
+		Metadata:        convertMetadata(charge.Metadata),
+		CreatedAt:       convertTime(charge.CreatedAt),
+		UpdatedAt:       convertTime(charge.UpdatedAt),
+		ProviderId:      charge.ProviderID,
+		Provider:        charge.Provider,
+	}
+}
+
+// convertCoveredCharge describes a charge paid entirely from customer
+// credit, which no provider was asked to charge
+func convertCoveredCharge(request services.CreateChargeRequest, application *customerbalance.Application) *paymentsv1.Charge {
+	return &paymentsv1.Charge{
+		Id:              application.Transaction.ID,
+		Amount:          application.Applied,
+		Currency:        request.Currency,
+		CustomerId:      request.CustomerID,
+		PaymentMethodId: request.PaymentMethodID,
+		Status:          "succeeded",
+		Description:     request.Description,
+		Metadata:        convertMetadata(request.Metadata),
+		CreatedAt:       convertTime(application.Transaction.CreatedAt),
+		UpdatedAt:       convertTime(application.Transaction.CreatedAt),
+		Provider:        "customer_balance",
+	}
+}
+
+// convertRefund converts a gateway refund to its message
+func convertRefund(refund *services.Refund) *paymentsv1.Refund {
+	return &paymentsv1.Refund{
+		Id:         refund.ID,
+		ChargeId:   refund.ChargeID,
+		Amount:     refund.Amount,
+		Currency:   refund.Currency,
+		Reason:     refund.Reason,
+		Status:     refund.Status,
+		Metadata:   convertMetadata(refund.Metadata),
+		CreatedAt:  convertTime(refund.CreatedAt),
+		UpdatedAt:  convertTime(refund.UpdatedAt),
+		ProviderId: refund.ProviderID,
+		Provider:   refund.Provider,
+	}
+}
+
+// convertDispute converts a gateway dispute to its message
+func convertDispute(dispute *services.Dispute) *paymentsv1.Dispute {
+	return &paymentsv1.Dispute{
+		Id:            dispute.ID,
+		ChargeId:      dispute.ChargeID,
+		Amount:        dispute.Amount,
+		Currency:      dispute.Currency,
+		Reason:        dispute.Reason,
+		Status:        dispute.Status,
+		EvidenceDueBy: convertTime(dispute.EvidenceDueBy),
+		CreatedAt:     convertTime(dispute.CreatedAt),
+		UpdatedAt:     convertTime(dispute.UpdatedAt),
+		ProviderId:    dispute.ProviderID,
+		Provider:      dispute.Provider,
+	}
+}
+
+// convertSubscription converts a gateway subscription to its message
+func convertSubscription(subscription *services.Subscription) *paymentsv1.Subscription {
+	return &paymentsv1.Subscription{
+		Id:                 subscription.ID,
+		CustomerId:         subscription.CustomerID,
+		PlanId:             subscription.PlanID,
+		Status:             subscription.Status,
+		CurrentPeriodStart: convertTime(subscription.CurrentPeriodStart),
+		CurrentPeriodEnd:   convertTime(subscription.CurrentPeriodEnd),
+		Metadata:           convertMetadata(subscription.Metadata),
+		CreatedAt:          convertTime(subscription.CreatedAt),
+		UpdatedAt:          convertTime(subscription.UpdatedAt),
+		ProviderId:         subscription.ProviderID,
+		Provider:           subscription.Provider,
+	}
+}
+
+// convertAddress converts a gateway address to its message
+func convertAddress(address *services.Address) *paymentsv1.Address {
+	if address == nil {
+		return nil
+	}
+	return &paymentsv1.Address{
+		Line1:      address.Line1,
+		Line2:      address.Line2,
+		City:       address.City,
+		State:      address.State,
+		PostalCode: address.PostalCode,
+		Country:    address.Country,
+	}
+}
+
+// convertAddressRequest converts an address message for a gateway request
+func convertAddressRequest(address *paymentsv1.Address) *services.Address {
+	if address == nil {
+		return nil
+	}
+	return &services.Address{
+		Line1:      address.GetLine1(),
+		Line2:      address.GetLine2(),
+		City:       address.GetCity(),
+		State:      address.GetState(),
+		PostalCode: address.GetPostalCode(),
+		Country:    address.GetCountry(),
+	}
+}
+
+// convertCardRequest converts a card message for a gateway request
+func convertCardRequest(card *paymentsv1.Card) *services.Card {
+	if card == nil {
+		return nil
+	}
+	return &services.Card{
+		Brand:       card.GetBrand(),
+		Last4:       card.GetLast4(),
+		ExpMonth:    int(card.GetExpMonth()),
+		ExpYear:     int(card.GetExpYear()),
+		Fingerprint: card.GetFingerprint(),
+		Country:     card.GetCountry(),
+	}
+}
+
+// convertBankAccountRequest converts a bank account message for a gateway request
+func convertBankAccountRequest(account *paymentsv1.BankAccount) *services.BankAccount {
+	if account == nil {
+		return nil
+	}
+	return &services.BankAccount{
+		BankName:      account.GetBankName(),
+		Last4:         account.GetLast4(),
+		RoutingNumber: account.GetRoutingNumber(),
+		AccountType:   account.GetAccountType(),
+		Country:       account.GetCountry(),
+	}
+}
+
+// convertMetadata converts gateway metadata to string values
+func convertMetadata(metadata map[string]interface{}) map[string]string {
+	if len(metadata) == 0 {
+		return nil
+	}
+	converted := make(map[string]string, len(metadata))
+	for key, value := range metadata {
+		converted[key] = fmt.Sprint(value)
+	}
+	return converted
+}
+
+// convertMetadataRequest converts metadata for a gateway request
+func convertMetadataRequest(metadata map[string]string) map[string]interface{} {
+	if len(metadata) == 0 {
+		return nil
+	}
+	converted := make(map[string]interface{}, len(metadata))
+	for key, value := range metadata {
+		converted[key] = value
+	}
+	return converted
+}
+
+// convertTime converts a time, leaving zero times unset
+func convertTime(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
@@ -0,0 +1,337 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"apis/payments/services"
+	"apis/payments/services/auth"
+	"apis/payments/services/authorizations"
+	"apis/payments/services/budgets"
+	"apis/payments/services/chargestate"
+	"apis/payments/services/customerbalance"
+	"apis/payments/services/disputes"
+	"apis/payments/services/fingerprints"
+	"apis/payments/services/fraud"
+	"apis/payments/services/holds"
+	metadataschemas "apis/payments/services/metadata"
+	"apis/payments/services/refundguard"
+	"apis/payments/services/requestid"
+	"apis/payments/services/resilience"
+	"apis/payments/services/tenancy"
+
+	stripego "github.com/stripe/stripe-go/v76"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Metadata keys read from every call
+const (
+	authorizationKey = "authorization"
+	tenantKey        = "x-tenant-id"
+	providerKey      = "x-payment-provider"
+)
+
+// ErrTLSRequired is returned when the gRPC API is enabled without a
+// certificate to serve it with
+var ErrTLSRequired = errors.New("the gRPC API requires GRPC_TLS_CERT_FILE and GRPC_TLS_KEY_FILE")
+
+// ErrChargeNeedsReview is returned for charges fraud screening sends to
+// review, which only REST charges can be held for
+var ErrChargeNeedsReview = errors.New("charge needs fraud review; create it over REST to have it held for review")
+
+// ErrRefundPendingApproval is returned for refunds the refund guard holds for
+// step-up approval, which issues them once an operator approves
+var ErrRefundPendingApproval = errors.New("refund is held for step-up approval")
+
+// Config controls the gRPC API. It is off by default and, when enabled, is
+// only served over TLS.
+type Config struct {
+	Enabled  bool
+	Port     string // Served apart from the REST API
+	CertFile string
+	KeyFile  string
+}
+
+// LoadConfig loads the gRPC configuration from environment variables
+func LoadConfig() *Config {
+	config := &Config{
+		Enabled:  false,
+		Port:     "9091",
+		CertFile: os.Getenv("GRPC_TLS_CERT_FILE"),
+		KeyFile:  os.Getenv("GRPC_TLS_KEY_FILE"),
+	}
+
+	if enabled, err := strconv.ParseBool(os.Getenv("GRPC_ENABLED")); err == nil {
+		config.Enabled = enabled
+	}
+	if port := os.Getenv("GRPC_PORT"); port != "" {
+		config.Port = port
+	}
+
+	return config
+}
+
+// Credentials loads the TLS certificate the API is served with
+func (c *Config) Credentials() (grpc.ServerOption, error) {
+	if c.CertFile == "" || c.KeyFile == "" {
+		return nil, ErrTLSRequired
+	}
+
+	creds, err := credentials.NewServerTLSFromFile(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load gRPC TLS certificate: %w", err)
+	}
+
+	return grpc.Creds(creds), nil
+}
+
+// GatewaySource returns the gateway acting on a tenant's provider account
+type GatewaySource interface {
+	Gateway(ctx context.Context, tenantID, provider string) (services.PaymentGateway, error)
+}
+
+// AuthorizationTracker tracks charges created without capture and captures
+// them within what remains authorized
+type AuthorizationTracker interface {
+	Track(ctx context.Context, tenantID string, charge *services.Charge, multiCapture bool) (*authorizations.Authorization, error)
+	Capture(ctx context.Context, tenantID, chargeID string, request services.CaptureChargeRequest) (*authorizations.Authorization, *services.Charge, error)
+}
+
+// Authenticator verifies API keys and bearer tokens
+type Authenticator interface {
+	Enabled() bool
+	Authenticate(ctx context.Context, credential string) (*auth.Principal, error)
+}
+
+// TenantResolver returns the configuration of a tenant that may make calls
+type TenantResolver interface {
+	Resolve(ctx context.Context, tenantID string) (*tenancy.Tenant, error)
+}
+
+// ChargePolicy applies the checks REST charges go through: customer holds,
+// spend budgets, card fingerprint blocks, fraud screening and customer
+// credit. Admit may lower the amount left to charge; the credit it applies
+// is recorded against the charge once created, or reversed if it fails.
+type ChargePolicy interface {
+	Admit(ctx context.Context, tenantID string, request *services.CreateChargeRequest) (*customerbalance.Application, error)
+	Record(ctx context.Context, tenantID string, application *customerbalance.Application, charge *services.Charge)
+	Reverse(ctx context.Context, application *customerbalance.Application)
+}
+
+// RefundPolicy issues refunds the way REST refunds are issued: only charges
+// that can still be refunded, with metadata the tenant's schema accepts, and
+// through the refund guard, which holds refunds over its velocity limits or
+// approval threshold for step-up approval. Exactly one of the returned refund
+// and approval is non-nil on success.
+type RefundPolicy interface {
+	CreateRefund(ctx context.Context, actor refundguard.Actor, request services.CreateRefundRequest) (*services.Refund, *refundguard.Approval, error)
+}
+
+// DisputePolicy responds to disputes the way REST does: evidence is staged
+// on the dispute and submitted to the bank as a whole, and only disputes
+// still awaiting a response before their deadline can be answered
+type DisputePolicy interface {
+	StageEvidence(ctx context.Context, disputeID string, fields map[string]string, operatorID string) error
+	SubmitEvidence(ctx context.Context, disputeID, operatorID string) error
+	AcceptDispute(ctx context.Context, disputeID, operatorID string) error
+}
+
+// NewServer returns a gRPC server for a service. Calls are traced and given
+// a request ID, then authenticated with the same API keys and tokens as the
+// REST API, then bound to their tenant and provider, then rate limited,
+// quarantined and audited like the REST routes they correspond to.
+func NewServer(service *Service, options ...grpc.ServerOption) *grpc.Server {
+	options = append([]grpc.ServerOption{
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(
+			assignRequestID, service.authenticate, service.resolveTenant, service.selectProvider,
+			service.rateLimit, service.quarantineGate, service.auditCalls,
+		),
+	}, options...)
+
+	server := grpc.NewServer(options...)
+	service.Register(server)
+	return server
+}
+
+// principalKey is the context key of the call's principal
+type principalKey struct{}
+
+// tenantConfigKey is the context key of the call's tenant configuration
+type tenantConfigKey struct{}
+
+// selectedProviderKey is the context key of the provider a call selected
+type selectedProviderKey struct{}
+
+// assignRequestID takes the caller's x-request-id metadata, or generates an
+// ID when it is missing or malformed, tags the call's span with it and
+// returns it in the response headers
+func assignRequestID(ctx context.Context, request any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	id := requestid.Resolve(firstMetadata(ctx, requestid.MetadataKey))
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String(requestid.Attribute, id))
+	if err := grpc.SetHeader(ctx, metadata.Pairs(requestid.MetadataKey, id)); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return handler(requestid.WithID(ctx, id), request)
+}
+
+// authenticate requires an API key or bearer token in the authorization
+// metadata granting the scope each method needs
+func (s *Service) authenticate(ctx context.Context, request any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if !s.auth.Enabled() {
+		return handler(ctx, request)
+	}
+
+	credential := strings.TrimPrefix(firstMetadata(ctx, authorizationKey), "Bearer ")
+	principal, err := s.auth.Authenticate(ctx, credential)
+	if errors.Is(err, auth.ErrUnauthenticated) {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if !principal.Allows(RequiredScope(info.FullMethod)) {
+		return nil, status.Error(codes.PermissionDenied, auth.ErrScopeNotAllowed.Error())
+	}
+
+	return handler(context.WithValue(ctx, principalKey{}, principal), request)
+}
+
+// resolveTenant binds each call to its tenant, taken from the API key it was
+// authenticated with or the x-tenant-id metadata. Keys and tokens bound to a
+// tenant can only act for that tenant.
+func (s *Service) resolveTenant(ctx context.Context, request any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	tenantID := firstMetadata(ctx, tenantKey)
+	if principal, ok := ctx.Value(principalKey{}).(*auth.Principal); ok && principal.TenantID != "" {
+		if tenantID != "" && tenantID != principal.TenantID {
+			return nil, status.Error(codes.PermissionDenied, auth.ErrScopeNotAllowed.Error())
+		}
+		tenantID = principal.TenantID
+	}
+	if tenantID == "" {
+		tenantID = tenancy.DefaultTenantID
+	}
+
+	tenant, err := s.tenants.Resolve(ctx, tenantID)
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	ctx = tenancy.WithTenant(ctx, tenant.ID)
+	return handler(context.WithValue(ctx, tenantConfigKey{}, tenant), request)
+}
+
+// selectProvider binds calls naming a provider in the x-payment-provider
+// metadata to it, so one deployment serves several providers at once. Calls
+// naming none use the tenant's default provider.
+func (s *Service) selectProvider(ctx context.Context, request any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	provider, err := s.providers.Resolve(firstMetadata(ctx, providerKey))
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+	if provider != "" {
+		ctx = context.WithValue(ctx, selectedProviderKey{}, provider)
+	}
+	return handler(ctx, request)
+}
+
+// RequiredScope returns the scope a method needs: reads need ScopeRead, and
+// charge and refund creation their own write scopes like the REST routes
+func RequiredScope(fullMethod string) string {
+	method := path.Base(fullMethod)
+	switch {
+	case strings.HasPrefix(method, "Get"), strings.HasPrefix(method, "List"):
+		return auth.ScopeRead
+	case method == "CreateCharge", method == "CaptureCharge":
+		return auth.ScopeChargesWrite
+	case method == "CreateRefund":
+		return auth.ScopeRefundsWrite
+	default:
+		return auth.ScopeWrite
+	}
+}
+
+// firstMetadata returns the first value of an incoming metadata key
+func firstMetadata(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// errorStatus maps tenancy, charge and refund check, dispute evidence and
+// provider errors to gRPC statuses, provider errors by the HTTP status they
+// were answered with
+func errorStatus(err error) error {
+	switch {
+	case errors.Is(err, tenancy.ErrUnknownTenant), errors.Is(err, tenancy.ErrTenantSuspended):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, services.ErrProviderNotEnabled):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, tenancy.ErrNoCredentials):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, resilience.ErrCircuitOpen):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, authorizations.ErrExceedsAuthorization), errors.Is(err, authorizations.ErrSingleCapture):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, authorizations.ErrAuthorizationClosed), errors.Is(err, authorizations.ErrAuthorizationExpired):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, holds.ErrCustomerOnHold), errors.Is(err, budgets.ErrBudgetExceeded),
+		errors.Is(err, fingerprints.ErrBlocked), errors.Is(err, fraud.ErrRejected):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, ErrChargeNeedsReview), errors.Is(err, ErrRefundPendingApproval):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, refundguard.ErrRefundPolicy), errors.Is(err, chargestate.ErrIllegalTransition):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, chargestate.ErrConcurrentTransition):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, metadataschemas.ErrInvalidMetadata), errors.Is(err, disputes.ErrInvalidEvidence):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, disputes.ErrDisputeClosed), errors.Is(err, disputes.ErrEvidencePastDue), errors.Is(err, disputes.ErrNoEvidence):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	httpStatus := 0
+	var stripeErr *stripego.Error
+	var statusErr interface{ HTTPStatus() int }
+	switch {
+	case errors.As(err, &stripeErr):
+		httpStatus = stripeErr.HTTPStatusCode
+	case errors.As(err, &statusErr):
+		httpStatus = statusErr.HTTPStatus()
+	}
+	switch httpStatus {
+	case http.StatusBadRequest:
+		return status.Error(codes.InvalidArgument, err.Error())
+	case http.StatusPaymentRequired:
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case http.StatusForbidden:
+		return status.Error(codes.PermissionDenied, err.Error())
+	case http.StatusNotFound:
+		return status.Error(codes.NotFound, err.Error())
+	case http.StatusConflict:
+		return status.Error(codes.Aborted, err.Error())
+	case http.StatusTooManyRequests:
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+
+	return status.Error(codes.Internal, err.Error())
+}
@@ -0,0 +1,273 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"apis/payments/services/audit"
+	"apis/payments/services/auth"
+	"apis/payments/services/quarantine"
+	"apis/payments/services/ratelimit"
+	"apis/payments/services/requestid"
+	"apis/payments/services/tenancy"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// operatorKey is the metadata key naming the operator acting for a caller
+const operatorKey = "x-operator-id"
+
+// ErrQuarantined is returned for mutations by quarantined callers, which
+// can only be held for release when made over REST
+var ErrQuarantined = errors.New("mutations from quarantined callers are only accepted over REST, where they are held for release")
+
+// RateLimiter limits callers to per-endpoint token buckets
+type RateLimiter interface {
+	Enabled() bool
+	Allow(ctx context.Context, subject, method, path string) (*ratelimit.Decision, error)
+}
+
+// QuarantineGate flags calls by quarantined API keys and tenants
+type QuarantineGate interface {
+	Check(ctx context.Context, caller quarantine.Caller) (*quarantine.Quarantine, error)
+	Flag(ctx context.Context, q *quarantine.Quarantine, caller quarantine.Caller, request *quarantine.Request) (*quarantine.HeldMutation, error)
+	Refuse(ctx context.Context, q *quarantine.Quarantine, caller quarantine.Caller, request *quarantine.Request) error
+}
+
+// AuditLog records mutating calls
+type AuditLog interface {
+	Record(ctx context.Context, call *audit.Call) (*audit.Entry, error)
+}
+
+// UseRateLimits draws calls from the same token buckets as the REST routes
+// they correspond to
+func (s *Service) UseRateLimits(limiter RateLimiter) {
+	s.rateLimits = limiter
+}
+
+// UseQuarantine flags calls by quarantined callers and refuses their
+// mutations
+func (s *Service) UseQuarantine(gate QuarantineGate) {
+	s.quarantine = gate
+}
+
+// UseAuditLog records successful mutations in the audit log
+func (s *Service) UseAuditLog(auditLog AuditLog) {
+	s.auditLog = auditLog
+}
+
+// restRoute is the REST route a method corresponds to
+type restRoute struct {
+	method string
+	route  string
+}
+
+// restRoutes maps each method to its REST route, so limits, quarantine
+// activity and audit entries name calls the same way whichever API made them
+var restRoutes = map[string]restRoute{
+	"CreateCustomer":        {http.MethodPost, "/customers"},
+	"GetCustomer":           {http.MethodGet, "/customers/:id"},
+	"UpdateCustomer":        {http.MethodPut, "/customers/:id"},
+	"DeleteCustomer":        {http.MethodDelete, "/customers/:id"},
+	"ListCustomers":         {http.MethodGet, "/customers"},
+	"AddPaymentMethod":      {http.MethodPost, "/customers/:customerId/payment-methods"},
+	"RemovePaymentMethod":   {http.MethodDelete, "/customers/:customerId/payment-methods/:id"},
+	"ListPaymentMethods":    {http.MethodGet, "/customers/:customerId/payment-methods"},
+	"CreateCharge":          {http.MethodPost, "/charges"},
+	"GetCharge":             {http.MethodGet, "/charges/:id"},
+	"CaptureCharge":         {http.MethodPost, "/authorizations/:id/capture"},
+	"ListCharges":           {http.MethodGet, "/charges"},
+	"CreateRefund":          {http.MethodPost, "/refunds"},
+	"GetRefund":             {http.MethodGet, "/refunds/:id"},
+	"ListRefunds":           {http.MethodGet, "/refunds"},
+	"GetDispute":            {http.MethodGet, "/disputes/:id"},
+	"ListDisputes":          {http.MethodGet, "/disputes"},
+	"AcceptDispute":         {http.MethodPost, "/disputes/:id/accept"},
+	"SubmitDisputeEvidence": {http.MethodPost, "/disputes/:id/evidence/submit"},
+	"CreateSubscription":    {http.MethodPost, "/subscriptions"},
+	"GetSubscription":       {http.MethodGet, "/subscriptions/:id"},
+	"UpdateSubscription":    {http.MethodPut, "/subscriptions/:id"},
+	"CancelSubscription":    {http.MethodPost, "/subscriptions/:id/cancel"},
+	"ListSubscriptions":     {http.MethodGet, "/subscriptions"},
+}
+
+// restCall describes a call as the REST request it corresponds to
+type restCall struct {
+	method string
+	route  string
+	path   string
+	params map[string]string
+}
+
+// describeCall returns the REST request a call corresponds to. Methods
+// without one are described as POSTs to their full method name.
+func describeCall(fullMethod string, request any) restCall {
+	route, ok := restRoutes[path.Base(fullMethod)]
+	if !ok {
+		return restCall{method: http.MethodPost, route: fullMethod, path: fullMethod, params: map[string]string{}}
+	}
+
+	params := map[string]string{}
+	if r, ok := request.(interface{ GetId() string }); ok {
+		params["id"] = r.GetId()
+	}
+	if r, ok := request.(interface{ GetCustomerId() string }); ok && strings.Contains(route.route, ":customerId") {
+		params["customerId"] = r.GetCustomerId()
+	}
+	if r, ok := request.(interface{ GetPaymentMethodId() string }); ok && strings.Contains(route.route, "/:id") && params["id"] == "" {
+		params["id"] = r.GetPaymentMethodId()
+	}
+
+	segments := strings.Split(route.route, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = params[strings.TrimPrefix(segment, ":")]
+		}
+	}
+
+	return restCall{method: route.method, route: route.route, path: strings.Join(segments, "/"), params: params}
+}
+
+// rateLimit limits each API key, or each tenant for other callers, to the
+// token buckets of the REST route a call corresponds to. Every response
+// carries the bucket's state in x-ratelimit-* headers; calls over the limit
+// fail with ResourceExhausted and a retry-after header.
+func (s *Service) rateLimit(ctx context.Context, request any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if s.rateLimits == nil || !s.rateLimits.Enabled() {
+		return handler(ctx, request)
+	}
+
+	subject := "tenant:" + tenancy.ID(ctx)
+	if principal, ok := ctx.Value(principalKey{}).(*auth.Principal); ok && principal.Type == auth.PrincipalAPIKey {
+		subject = "api_key:" + principal.ID
+	}
+
+	call := describeCall(info.FullMethod, request)
+	decision, err := s.rateLimits.Allow(ctx, subject, call.method, call.path)
+	if err != nil {
+		// An unreachable limiter must not take payments down with it
+		requestid.Logf(ctx, "Rate limiter unavailable, admitting call: %v", err)
+		return handler(ctx, request)
+	}
+
+	headers := metadata.Pairs(
+		"x-ratelimit-limit", strconv.Itoa(decision.Limit),
+		"x-ratelimit-remaining", strconv.Itoa(decision.Remaining),
+		"x-ratelimit-reset", strconv.Itoa(int(math.Ceil(decision.Reset.Seconds()))),
+	)
+	if !decision.Allowed {
+		headers.Set("retry-after", strconv.Itoa(int(math.Ceil(decision.RetryAfter.Seconds()))))
+	}
+	if err := grpc.SetHeader(ctx, headers); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if !decision.Allowed {
+		return nil, status.Error(codes.ResourceExhausted, ratelimit.ErrRateLimited.Error())
+	}
+
+	return handler(ctx, request)
+}
+
+// quarantineGate flags every call from a quarantined API key or tenant.
+// Reads go ahead; mutations are refused, since only REST mutations can be
+// held and replayed. Quarantine is checked before the call is served, so a
+// failed check rejects the call.
+func (s *Service) quarantineGate(ctx context.Context, request any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if s.quarantine == nil {
+		return handler(ctx, request)
+	}
+
+	caller := quarantine.Caller{
+		TenantID:   tenancy.ID(ctx),
+		APIKeyID:   auth.Fingerprint(strings.TrimPrefix(firstMetadata(ctx, authorizationKey), "Bearer ")),
+		OperatorID: firstMetadata(ctx, operatorKey),
+	}
+
+	active, err := s.quarantine.Check(ctx, caller)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	if active == nil {
+		return handler(ctx, request)
+	}
+
+	call := describeCall(info.FullMethod, request)
+	flagged := &quarantine.Request{Method: call.method, Path: info.FullMethod}
+	if !flagged.IsMutation() {
+		if _, err := s.quarantine.Flag(ctx, active, caller, flagged); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		return handler(ctx, request)
+	}
+
+	if err := s.quarantine.Refuse(ctx, active, caller, flagged); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return nil, status.Error(codes.PermissionDenied, ErrQuarantined.Error())
+}
+
+// auditCalls records every successful mutation in the audit log once it
+// has been served, under the REST route it corresponds to. Failing to
+// record is logged rather than failing a call that already took effect.
+func (s *Service) auditCalls(ctx context.Context, request any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	response, err := handler(ctx, request)
+	if err != nil || s.auditLog == nil {
+		return response, err
+	}
+
+	call := describeCall(info.FullMethod, request)
+	if !audit.Recorded(call.method, http.StatusOK) {
+		return response, nil
+	}
+
+	actorType, actorID := audit.ActorAnonymous, ""
+	if principal, ok := ctx.Value(principalKey{}).(*auth.Principal); ok {
+		actorType, actorID = principal.Type, principal.ID
+	}
+
+	var body []byte
+	if message, ok := response.(proto.Message); ok {
+		body, _ = protojson.MarshalOptions{UseProtoNames: true}.Marshal(message)
+	}
+
+	var ip string
+	if p, ok := peer.FromContext(ctx); ok {
+		ip = p.Addr.String()
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+	}
+
+	requestID, _ := requestid.FromContext(ctx)
+	entry := &audit.Call{
+		Method:     call.method,
+		Route:      call.route,
+		Path:       info.FullMethod,
+		Params:     call.params,
+		Status:     http.StatusOK,
+		Response:   body,
+		TenantID:   tenancy.ID(ctx),
+		ActorType:  actorType,
+		ActorID:    actorID,
+		OperatorID: firstMetadata(ctx, operatorKey),
+		IP:         ip,
+		RequestID:  requestID,
+	}
+	if _, err := s.auditLog.Record(ctx, entry); err != nil {
+		requestid.Logf(ctx, "Failed to audit %s: %v", info.FullMethod, err)
+	}
+
+	return response, nil
+}
@@ -0,0 +1,815 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	paymentsv1 "apis/payments/proto/payments/v1"
+	"apis/payments/services"
+	"apis/payments/services/auth"
+	"apis/payments/services/authorizations"
+	"apis/payments/services/customerbalance"
+	"apis/payments/services/refundguard"
+	"apis/payments/services/requestid"
+	"apis/payments/services/tenancy"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Service serves the gRPC API by delegating each call to the payment gateway
+// of the tenant it acts for, through the provider the call selects or the
+// tenant's default provider
+type Service struct {
+	paymentsv1.UnimplementedPaymentsServiceServer
+
+	gateways       GatewaySource
+	providers      *services.EnabledProviders
+	auth           Authenticator
+	tenants        TenantResolver
+	failover       *services.FailoverCharger
+	authorizations AuthorizationTracker
+	policy         ChargePolicy
+	refunds        RefundPolicy
+	disputes       DisputePolicy
+	rateLimits     RateLimiter
+	quarantine     QuarantineGate
+	auditLog       AuditLog
+}
+
+// NewService creates a new gRPC API service
+func NewService(gateways GatewaySource, authenticator Authenticator, tenants TenantResolver) *Service {
+	return &Service{
+		gateways:  gateways,
+		providers: services.NewEnabledProviders(services.GetFactory().GetSupportedProviders()),
+		auth:      authenticator,
+		tenants:   tenants,
+	}
+}
+
+// UseProviders limits the providers calls may select, which is every
+// registered provider by default
+func (s *Service) UseProviders(providers *services.EnabledProviders) {
+	s.providers = providers
+}
+
+// UseFailover routes charges through the provider chains of a router,
+// falling back to the next provider when one is down. A routing policy, if
+// not nil, orders each charge's providers.
+func (s *Service) UseFailover(router services.ChargeRouter, policy services.RoutingPolicy) {
+	s.failover = services.NewFailoverCharger(s.gateways, router)
+	s.failover.UsePolicy(policy)
+}
+
+// UseAuthorizations tracks charges created without capture, so they are
+// captured within what remains authorized and voided before they expire
+func (s *Service) UseAuthorizations(tracker AuthorizationTracker) {
+	s.authorizations = tracker
+}
+
+// UseChargePolicy runs charges through the checks REST charges go through
+// before they reach a provider
+func (s *Service) UseChargePolicy(policy ChargePolicy) {
+	s.policy = policy
+}
+
+// UseRefundPolicy issues refunds through the checks and refund guard REST
+// refunds go through rather than straight at the tenant's gateway
+func (s *Service) UseRefundPolicy(policy RefundPolicy) {
+	s.refunds = policy
+}
+
+// UseDisputePolicy answers disputes through the evidence flow REST disputes
+// go through rather than straight at the tenant's gateway
+func (s *Service) UseDisputePolicy(policy DisputePolicy) {
+	s.disputes = policy
+}
+
+// Register registers the service with a gRPC server
+func (s *Service) Register(server grpc.ServiceRegistrar) {
+	paymentsv1.RegisterPaymentsServiceServer(server, s)
+}
+
+// CreateCustomer creates a customer
+func (s *Service) CreateCustomer(ctx context.Context, request *paymentsv1.CreateCustomerRequest) (*paymentsv1.Customer, error) {
+	gateway, err := s.gateway(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	customer, err := gateway.CreateCustomer(ctx, services.CreateCustomerRequest{
+		Email:    request.GetEmail(),
+		Name:     request.GetName(),
+		Phone:    request.GetPhone(),
+		Address:  convertAddressRequest(request.GetAddress()),
+		Metadata: convertMetadataRequest(request.GetMetadata()),
+	})
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return convertCustomer(customer), nil
+}
+
+// GetCustomer retrieves a customer
+func (s *Service) GetCustomer(ctx context.Context, request *paymentsv1.GetCustomerRequest) (*paymentsv1.Customer, error) {
+	if err := requireField("id", request.GetId()); err != nil {
+		return nil, err
+	}
+	gateway, err := s.gateway(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	customer, err := gateway.GetCustomer(ctx, request.GetId())
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return convertCustomer(customer), nil
+}
+
+// UpdateCustomer updates a customer
+func (s *Service) UpdateCustomer(ctx context.Context, request *paymentsv1.UpdateCustomerRequest) (*paymentsv1.Customer, error) {
+	if err := requireField("id", request.GetId()); err != nil {
+		return nil, err
+	}
+	gateway, err := s.gateway(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	customer, err := gateway.UpdateCustomer(ctx, request.GetId(), services.UpdateCustomerRequest{
+		Email:    request.GetEmail(),
+		Name:     request.GetName(),
+		Phone:    request.GetPhone(),
+		Address:  convertAddressRequest(request.GetAddress()),
+		Metadata: convertMetadataRequest(request.GetMetadata()),
+	})
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return convertCustomer(customer), nil
+}
+
+// DeleteCustomer deletes a customer
+func (s *Service) DeleteCustomer(ctx context.Context, request *paymentsv1.DeleteCustomerRequest) (*paymentsv1.DeleteCustomerResponse, error) {
+	if err := requireField("id", request.GetId()); err != nil {
+		return nil, err
+	}
+	gateway, err := s.gateway(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := gateway.DeleteCustomer(ctx, request.GetId()); err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return &paymentsv1.DeleteCustomerResponse{}, nil
+}
+
+// ListCustomers lists customers
+func (s *Service) ListCustomers(ctx context.Context, request *paymentsv1.ListCustomersRequest) (*paymentsv1.ListCustomersResponse, error) {
+	gateway, err := s.gateway(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := gateway.ListCustomers(ctx, services.ListCustomersRequest{
+		Limit:  int(request.GetPage().GetLimit()),
+		Cursor: request.GetPage().GetCursor(),
+		Email:  request.GetEmail(),
+	})
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	response := &paymentsv1.ListCustomersResponse{HasMore: list.HasMore, NextCursor: list.NextCursor}
+	for _, customer := range list.Customers {
+		response.Customers = append(response.Customers, convertCustomer(customer))
+	}
+	return response, nil
+}
+
+// AddPaymentMethod adds a payment method to a customer
+func (s *Service) AddPaymentMethod(ctx context.Context, request *paymentsv1.AddPaymentMethodRequest) (*paymentsv1.PaymentMethod, error) {
+	if err := requireField("customer_id", request.GetCustomerId()); err != nil {
+		return nil, err
+	}
+	gateway, err := s.gateway(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	method, err := gateway.AddPaymentMethod(ctx, request.GetCustomerId(), services.AddPaymentMethodRequest{
+		Type:        request.GetType(),
+		Card:        convertCardRequest(request.GetCard()),
+		BankAccount: convertBankAccountRequest(request.GetBankAccount()),
+		Metadata:    convertMetadataRequest(request.GetMetadata()),
+	})
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return convertPaymentMethod(method), nil
+}
+
+// RemovePaymentMethod removes a payment method from a customer
+func (s *Service) RemovePaymentMethod(ctx context.Context, request *paymentsv1.RemovePaymentMethodRequest) (*paymentsv1.RemovePaymentMethodResponse, error) {
+	if err := requireField("customer_id", request.GetCustomerId()); err != nil {
+		return nil, err
+	}
+	if err := requireField("payment_method_id", request.GetPaymentMethodId()); err != nil {
+		return nil, err
+	}
+	gateway, err := s.gateway(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := gateway.RemovePaymentMethod(ctx, request.GetCustomerId(), request.GetPaymentMethodId()); err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return &paymentsv1.RemovePaymentMethodResponse{}, nil
+}
+
+// ListPaymentMethods lists a customer's payment methods
+func (s *Service) ListPaymentMethods(ctx context.Context, request *paymentsv1.ListPaymentMethodsRequest) (*paymentsv1.ListPaymentMethodsResponse, error) {
+	if err := requireField("customer_id", request.GetCustomerId()); err != nil {
+		return nil, err
+	}
+	gateway, err := s.gateway(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := gateway.ListPaymentMethods(ctx, request.GetCustomerId(), services.ListPaymentMethodsRequest{
+		Limit:  int(request.GetPage().GetLimit()),
+		Cursor: request.GetPage().GetCursor(),
+	})
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	response := &paymentsv1.ListPaymentMethodsResponse{HasMore: list.HasMore, NextCursor: list.NextCursor}
+	for _, method := range list.PaymentMethods {
+		response.PaymentMethods = append(response.PaymentMethods, convertPaymentMethod(method))
+	}
+	return response, nil
+}
+
+// CreateCharge creates a charge
+func (s *Service) CreateCharge(ctx context.Context, request *paymentsv1.CreateChargeRequest) (*paymentsv1.Charge, error) {
+	if request.GetAmount() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "amount must be positive")
+	}
+	if err := requireField("currency", request.GetCurrency()); err != nil {
+		return nil, err
+	}
+	chargeRequest := services.CreateChargeRequest{
+		Amount:          request.GetAmount(),
+		Currency:        request.GetCurrency(),
+		CustomerID:      request.GetCustomerId(),
+		PaymentMethodID: request.GetPaymentMethodId(),
+		Description:     request.GetDescription(),
+		Metadata:        convertMetadataRequest(request.GetMetadata()),
+		Capture:         request.GetCapture(),
+		Country:         request.GetCountry(),
+	}
+
+	// Charges are held, budgeted, screened and paid from customer credit
+	// like REST charges; those the credit covers charge nothing
+	var application *customerbalance.Application
+	if s.policy != nil {
+		var err error
+		application, err = s.policy.Admit(ctx, tenancy.ID(ctx), &chargeRequest)
+		if err != nil {
+			return nil, errorStatus(err)
+		}
+		if application != nil && application.Covered() {
+			return convertCoveredCharge(chargeRequest, application), nil
+		}
+	}
+
+	charge, err := s.createCharge(ctx, chargeRequest)
+	if err != nil {
+		if s.policy != nil {
+			s.policy.Reverse(ctx, application)
+		}
+		return nil, err
+	}
+	if s.policy != nil {
+		s.policy.Record(ctx, tenancy.ID(ctx), application, charge)
+	}
+	s.trackAuthorization(ctx, chargeRequest, charge)
+
+	return convertCharge(charge), nil
+}
+
+// createCharge creates a charge with the provider the call selected, or
+// along the provider chain routing rules pick for it
+func (s *Service) createCharge(ctx context.Context, request services.CreateChargeRequest) (*services.Charge, error) {
+	// Charges selecting a provider go to it alone rather than along a chain
+	if _, selected := ctx.Value(selectedProviderKey{}).(string); s.failover != nil && !selected {
+		return s.createChargeWithFailover(ctx, request)
+	}
+
+	gateway, err := s.gateway(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	charge, err := gateway.CreateCharge(ctx, request)
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return charge, nil
+}
+
+// createChargeWithFailover charges through the provider chain routing rules
+// pick for the charge
+func (s *Service) createChargeWithFailover(ctx context.Context, request services.CreateChargeRequest) (*services.Charge, error) {
+	tenant, ok := ctx.Value(tenantConfigKey{}).(*tenancy.Tenant)
+	if !ok {
+		return nil, status.Error(codes.Internal, "call is not bound to a tenant")
+	}
+
+	charge, attempts, err := s.failover.CreateCharge(ctx, tenant.ID, tenant.DefaultProvider, request)
+	if len(attempts) > 1 {
+		requestid.Logf(ctx, "Charge failover for tenant %s: %+v", tenant.ID, attempts)
+	}
+	if errors.Is(err, services.ErrNoFailoverProvider) {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return charge, nil
+}
+
+// trackAuthorization starts tracking a charge created without capture.
+// The charge exists either way, so failures are only logged.
+func (s *Service) trackAuthorization(ctx context.Context, request services.CreateChargeRequest, charge *services.Charge) {
+	if s.authorizations == nil || request.Capture {
+		return
+	}
+
+	tenant, _ := ctx.Value(tenantConfigKey{}).(*tenancy.Tenant)
+	if tenant == nil {
+		return
+	}
+	if _, err := s.authorizations.Track(ctx, tenant.ID, charge, request.MultiCapture); err != nil {
+		requestid.Logf(ctx, "Failed to track authorization of charge %s: %v", charge.ID, err)
+	}
+}
+
+// GetCharge retrieves a charge
+func (s *Service) GetCharge(ctx context.Context, request *paymentsv1.GetChargeRequest) (*paymentsv1.Charge, error) {
+	if err := requireField("id", request.GetId()); err != nil {
+		return nil, err
+	}
+	gateway, err := s.gateway(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	charge, err := gateway.GetCharge(ctx, request.GetId())
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return convertCharge(charge), nil
+}
+
+// CaptureCharge captures an authorized charge. Tracked authorizations are
+// captured through the provider that authorized them.
+func (s *Service) CaptureCharge(ctx context.Context, request *paymentsv1.CaptureChargeRequest) (*paymentsv1.Charge, error) {
+	if err := requireField("id", request.GetId()); err != nil {
+		return nil, err
+	}
+	if tenant, ok := ctx.Value(tenantConfigKey{}).(*tenancy.Tenant); ok && s.authorizations != nil {
+		_, charge, err := s.authorizations.Capture(ctx, tenant.ID, request.GetId(), services.CaptureChargeRequest{Amount: request.GetAmount()})
+		if err == nil {
+			return convertCharge(charge), nil
+		}
+		if !errors.Is(err, authorizations.ErrAuthorizationNotFound) {
+			return nil, errorStatus(err)
+		}
+	}
+
+	gateway, err := s.gateway(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	charge, err := gateway.CaptureCharge(ctx, request.GetId(), services.CaptureChargeRequest{Amount: request.GetAmount()})
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return convertCharge(charge), nil
+}
+
+// ListCharges lists charges
+func (s *Service) ListCharges(ctx context.Context, request *paymentsv1.ListChargesRequest) (*paymentsv1.ListChargesResponse, error) {
+	gateway, err := s.gateway(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := gateway.ListCharges(ctx, services.ListChargesRequest{
+		Limit:      int(request.GetPage().GetLimit()),
+		Cursor:     request.GetPage().GetCursor(),
+		CustomerID: request.GetCustomerId(),
+		Status:     request.GetStatus(),
+	})
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	response := &paymentsv1.ListChargesResponse{HasMore: list.HasMore, NextCursor: list.NextCursor}
+	for _, charge := range list.Charges {
+		response.Charges = append(response.Charges, convertCharge(charge))
+	}
+	return response, nil
+}
+
+// CreateRefund refunds a charge
+func (s *Service) CreateRefund(ctx context.Context, request *paymentsv1.CreateRefundRequest) (*paymentsv1.Refund, error) {
+	if err := requireField("charge_id", request.GetChargeId()); err != nil {
+		return nil, err
+	}
+	if request.GetAmount() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "amount must not be negative")
+	}
+	refundRequest := services.CreateRefundRequest{
+		ChargeID: request.GetChargeId(),
+		Amount:   request.GetAmount(),
+		Reason:   request.GetReason(),
+	}
+
+	// Refunds are held by the refund guard like REST refunds, so a leaked key
+	// cannot mass-refund over gRPC instead
+	if s.refunds != nil {
+		refund, approval, err := s.refunds.CreateRefund(ctx, s.refundActor(ctx), refundRequest)
+		if err != nil {
+			return nil, errorStatus(err)
+		}
+		if approval != nil {
+			return nil, errorStatus(fmt.Errorf("%w as %s", ErrRefundPendingApproval, approval.ID))
+		}
+		return convertRefund(refund), nil
+	}
+
+	gateway, err := s.gateway(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	refund, err := gateway.CreateRefund(ctx, refundRequest)
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return convertRefund(refund), nil
+}
+
+// refundActor identifies the tenant, API key and operator behind a call the
+// way REST identifies them for the refund guard. Tokens name the operator
+// themselves; API keys may act for the operator in the x-operator-id
+// metadata.
+func (s *Service) refundActor(ctx context.Context) refundguard.Actor {
+	actor := refundguard.Actor{TenantID: tenancy.ID(ctx), OperatorID: firstMetadata(ctx, operatorKey)}
+
+	principal, ok := ctx.Value(principalKey{}).(*auth.Principal)
+	switch {
+	case !ok, principal.Type == auth.PrincipalAPIKey:
+		if credential := strings.TrimPrefix(firstMetadata(ctx, authorizationKey), "Bearer "); credential != "" {
+			actor.APIKeyID = auth.Fingerprint(credential)
+		}
+	default:
+		actor.OperatorID = principal.ID
+	}
+
+	return actor
+}
+
+// GetRefund retrieves a refund
+func (s *Service) GetRefund(ctx context.Context, request *paymentsv1.GetRefundRequest) (*paymentsv1.Refund, error) {
+	if err := requireField("id", request.GetId()); err != nil {
+		return nil, err
+	}
+	gateway, err := s.gateway(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	refund, err := gateway.GetRefund(ctx, request.GetId())
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return convertRefund(refund), nil
+}
+
+// ListRefunds lists refunds
+func (s *Service) ListRefunds(ctx context.Context, request *paymentsv1.ListRefundsRequest) (*paymentsv1.ListRefundsResponse, error) {
+	gateway, err := s.gateway(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := gateway.ListRefunds(ctx, services.ListRefundsRequest{
+		Limit:    int(request.GetPage().GetLimit()),
+		Cursor:   request.GetPage().GetCursor(),
+		ChargeID: request.GetChargeId(),
+	})
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	response := &paymentsv1.ListRefundsResponse{HasMore: list.HasMore, NextCursor: list.NextCursor}
+	for _, refund := range list.Refunds {
+		response.Refunds = append(response.Refunds, convertRefund(refund))
+	}
+	return response, nil
+}
+
+// GetDispute retrieves a dispute
+func (s *Service) GetDispute(ctx context.Context, request *paymentsv1.GetDisputeRequest) (*paymentsv1.Dispute, error) {
+	if err := requireField("id", request.GetId()); err != nil {
+		return nil, err
+	}
+	disputes, err := s.disputeManager(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dispute, err := disputes.GetDispute(ctx, request.GetId())
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return convertDispute(dispute), nil
+}
+
+// ListDisputes lists disputes
+func (s *Service) ListDisputes(ctx context.Context, request *paymentsv1.ListDisputesRequest) (*paymentsv1.ListDisputesResponse, error) {
+	disputes, err := s.disputeManager(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := disputes.ListDisputes(ctx, services.ListDisputesRequest{
+		Limit:    int(request.GetPage().GetLimit()),
+		Cursor:   request.GetPage().GetCursor(),
+		ChargeID: request.GetChargeId(),
+		Status:   request.GetStatus(),
+	})
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	response := &paymentsv1.ListDisputesResponse{HasMore: list.HasMore, NextCursor: list.NextCursor}
+	for _, dispute := range list.Disputes {
+		response.Disputes = append(response.Disputes, convertDispute(dispute))
+	}
+	return response, nil
+}
+
+// AcceptDispute concedes a dispute
+func (s *Service) AcceptDispute(ctx context.Context, request *paymentsv1.AcceptDisputeRequest) (*paymentsv1.Dispute, error) {
+	if err := requireField("id", request.GetId()); err != nil {
+		return nil, err
+	}
+	disputes, err := s.disputeManager(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.disputes != nil {
+		if err := s.disputes.AcceptDispute(ctx, request.GetId(), firstMetadata(ctx, operatorKey)); err != nil {
+			return nil, errorStatus(err)
+		}
+		return s.getDispute(ctx, disputes, request.GetId())
+	}
+
+	dispute, err := disputes.AcceptDispute(ctx, request.GetId())
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return convertDispute(dispute), nil
+}
+
+// SubmitDisputeEvidence adds evidence to a dispute
+func (s *Service) SubmitDisputeEvidence(ctx context.Context, request *paymentsv1.SubmitDisputeEvidenceRequest) (*paymentsv1.Dispute, error) {
+	if err := requireField("id", request.GetId()); err != nil {
+		return nil, err
+	}
+	disputes, err := s.disputeManager(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Evidence is staged like REST evidence and only submitted as a whole
+	if s.disputes != nil {
+		operatorID := firstMetadata(ctx, operatorKey)
+		fields := make(map[string]string, len(request.GetEvidence()))
+		for _, item := range request.GetEvidence() {
+			fields[item.GetType()] = item.GetText()
+		}
+		if len(fields) > 0 {
+			if err := s.disputes.StageEvidence(ctx, request.GetId(), fields, operatorID); err != nil {
+				return nil, errorStatus(err)
+			}
+		}
+		if request.GetSubmit() {
+			if err := s.disputes.SubmitEvidence(ctx, request.GetId(), operatorID); err != nil {
+				return nil, errorStatus(err)
+			}
+		}
+		return s.getDispute(ctx, disputes, request.GetId())
+	}
+
+	evidence := make([]services.DisputeEvidence, 0, len(request.GetEvidence()))
+	for _, item := range request.GetEvidence() {
+		evidence = append(evidence, services.DisputeEvidence{Type: item.GetType(), Text: item.GetText()})
+	}
+
+	dispute, err := disputes.SubmitDisputeEvidence(ctx, request.GetId(), services.SubmitDisputeEvidenceRequest{
+		Evidence: evidence,
+		Submit:   request.GetSubmit(),
+	})
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return convertDispute(dispute), nil
+}
+
+// CreateSubscription creates a subscription
+func (s *Service) CreateSubscription(ctx context.Context, request *paymentsv1.CreateSubscriptionRequest) (*paymentsv1.Subscription, error) {
+	if err := requireField("customer_id", request.GetCustomerId()); err != nil {
+		return nil, err
+	}
+	if err := requireField("plan_id", request.GetPlanId()); err != nil {
+		return nil, err
+	}
+	gateway, err := s.gateway(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	subscription, err := gateway.CreateSubscription(ctx, services.CreateSubscriptionRequest{
+		CustomerID: request.GetCustomerId(),
+		PlanID:     request.GetPlanId(),
+		Metadata:   convertMetadataRequest(request.GetMetadata()),
+	})
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return convertSubscription(subscription), nil
+}
+
+// GetSubscription retrieves a subscription
+func (s *Service) GetSubscription(ctx context.Context, request *paymentsv1.GetSubscriptionRequest) (*paymentsv1.Subscription, error) {
+	if err := requireField("id", request.GetId()); err != nil {
+		return nil, err
+	}
+	gateway, err := s.gateway(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	subscription, err := gateway.GetSubscription(ctx, request.GetId())
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return convertSubscription(subscription), nil
+}
+
+// UpdateSubscription changes a subscription's plan or metadata
+func (s *Service) UpdateSubscription(ctx context.Context, request *paymentsv1.UpdateSubscriptionRequest) (*paymentsv1.Subscription, error) {
+	if err := requireField("id", request.GetId()); err != nil {
+		return nil, err
+	}
+	gateway, err := s.gateway(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	subscription, err := gateway.UpdateSubscription(ctx, request.GetId(), services.UpdateSubscriptionRequest{
+		PlanID:   request.GetPlanId(),
+		Metadata: convertMetadataRequest(request.GetMetadata()),
+	})
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return convertSubscription(subscription), nil
+}
+
+// CancelSubscription cancels a subscription
+func (s *Service) CancelSubscription(ctx context.Context, request *paymentsv1.CancelSubscriptionRequest) (*paymentsv1.Subscription, error) {
+	if err := requireField("id", request.GetId()); err != nil {
+		return nil, err
+	}
+	gateway, err := s.gateway(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	subscription, err := gateway.CancelSubscription(ctx, request.GetId(), services.CancelSubscriptionRequest{
+		AtPeriodEnd: request.GetAtPeriodEnd(),
+	})
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return convertSubscription(subscription), nil
+}
+
+// ListSubscriptions lists subscriptions
+func (s *Service) ListSubscriptions(ctx context.Context, request *paymentsv1.ListSubscriptionsRequest) (*paymentsv1.ListSubscriptionsResponse, error) {
+	gateway, err := s.gateway(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := gateway.ListSubscriptions(ctx, services.ListSubscriptionsRequest{
+		Limit:      int(request.GetPage().GetLimit()),
+		Cursor:     request.GetPage().GetCursor(),
+		CustomerID: request.GetCustomerId(),
+		Status:     request.GetStatus(),
+	})
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	response := &paymentsv1.ListSubscriptionsResponse{HasMore: list.HasMore, NextCursor: list.NextCursor}
+	for _, subscription := range list.Subscriptions {
+		response.Subscriptions = append(response.Subscriptions, convertSubscription(subscription))
+	}
+	return response, nil
+}
+
+// gateway returns the gateway of the tenant a call acts for, at the provider
+// the call selected or the tenant's default provider
+func (s *Service) gateway(ctx context.Context) (services.PaymentGateway, error) {
+	tenant, ok := ctx.Value(tenantConfigKey{}).(*tenancy.Tenant)
+	if !ok {
+		return nil, status.Error(codes.Internal, "call is not bound to a tenant")
+	}
+
+	provider, ok := ctx.Value(selectedProviderKey{}).(string)
+	if !ok {
+		provider = tenant.DefaultProvider
+	}
+	gateway, err := s.gateways.Gateway(ctx, tenant.ID, provider)
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+	return gateway, nil
+}
+
+// disputeManager returns the tenant's gateway if it supports disputes
+func (s *Service) disputeManager(ctx context.Context) (services.DisputeManager, error) {
+	gateway, err := s.gateway(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	disputes, ok := gateway.(services.DisputeManager)
+	if !ok {
+		return nil, status.Errorf(codes.Unimplemented, "%s does not support disputes", gateway.GetProvider())
+	}
+	return disputes, nil
+}
+
+// getDispute returns a dispute as the tenant's gateway reports it
+func (s *Service) getDispute(ctx context.Context, disputes services.DisputeManager, disputeID string) (*paymentsv1.Dispute, error) {
+	dispute, err := disputes.GetDispute(ctx, disputeID)
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+	return convertDispute(dispute), nil
+}
+
+// requireField rejects calls missing a required field
+func requireField(name, value string) error {
+	if value == "" {
+		return status.Errorf(codes.InvalidArgument, "%s is required", name)
+	}
+	return nil
+}
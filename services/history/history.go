@@ -0,0 +1,48 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Entity types with recorded history
+const (
+	EntityCharge       = "charge"
+	EntitySubscription = "subscription"
+	EntityDispute      = "dispute"
+)
+
+// ErrNoVersion is returned when an entity has no recorded state at the requested time
+var ErrNoVersion = errors.New("no recorded version at that time")
+
+// Version is an immutable snapshot of an entity after a state change
+type Version struct {
+	ID         int64           `json:"id"`
+	Version    int             `json:"version"`
+	EntityType string          `json:"entity_type"`
+	EntityID   string          `json:"entity_id"`
+	Status     string          `json:"status,omitempty"`
+	State      json.RawMessage `json:"state"`
+	EventID    string          `json:"event_id"`
+	EventType  string          `json:"event_type"`
+	ValidFrom  time.Time       `json:"valid_from"` // When the provider made the change
+	RecordedAt time.Time       `json:"recorded_at"`
+}
+
+// Store persists entity versions. Versions are never updated or deleted.
+type Store interface {
+	RecordEntityVersion(ctx context.Context, version *Version) error
+	ListEntityVersions(ctx context.Context, entityType, entityID string) ([]*Version, error)
+	GetEntityVersionAsOf(ctx context.Context, entityType, entityID string, asOf time.Time) (*Version, error)
+}
+
+// IsEntity reports whether history is recorded for an entity type
+func IsEntity(entityType string) bool {
+	switch entityType {
+	case EntityCharge, EntitySubscription, EntityDispute:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package history
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Service records entity versions and answers history and as-of queries
+type Service struct {
+	store  Store
+	tracer trace.Tracer
+}
+
+// NewService creates a new history service
+func NewService(store Store) *Service {
+	return &Service{
+		store:  store,
+		tracer: otel.Tracer("payments.history"),
+	}
+}
+
+// Record stores a snapshot of an entity taken from a provider event
+func (s *Service) Record(ctx context.Context, entityType, eventID, eventType string, created int64, state json.RawMessage) error {
+	ctx, span := s.tracer.Start(ctx, "Record")
+	defer span.End()
+
+	var object struct {
+		ID     string `json:"id"`
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal(state, &object); err != nil {
+		return fmt.Errorf("failed to parse %s state: %w", entityType, err)
+	}
+	if object.ID == "" {
+		return fmt.Errorf("%s state has no ID", entityType)
+	}
+
+	return s.store.RecordEntityVersion(ctx, &Version{
+		EntityType: entityType,
+		EntityID:   object.ID,
+		Status:     object.Status,
+		State:      state,
+		EventID:    eventID,
+		EventType:  eventType,
+		ValidFrom:  time.Unix(created, 0).UTC(),
+	})
+}
+
+// History returns every version of an entity, oldest first
+func (s *Service) History(ctx context.Context, entityType, entityID string) ([]*Version, error) {
+	ctx, span := s.tracer.Start(ctx, "History")
+	defer span.End()
+
+	if !IsEntity(entityType) {
+		return nil, fmt.Errorf("unknown entity type: %s", entityType)
+	}
+	if entityID == "" {
+		return nil, fmt.Errorf("entity ID cannot be empty")
+	}
+
+	versions, err := s.store.ListEntityVersions(ctx, entityType, entityID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, version := range versions {
+		version.Version = i + 1
+	}
+
+	return versions, nil
+}
+
+// AsOf returns the version of an entity that was current at the given time
+func (s *Service) AsOf(ctx context.Context, entityType, entityID string, asOf time.Time) (*Version, error) {
+	ctx, span := s.tracer.Start(ctx, "AsOf")
+	defer span.End()
+
+	if !IsEntity(entityType) {
+		return nil, fmt.Errorf("unknown entity type: %s", entityType)
+	}
+	if entityID == "" {
+		return nil, fmt.Errorf("entity ID cannot be empty")
+	}
+
+	version, err := s.store.GetEntityVersionAsOf(ctx, entityType, entityID, asOf)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoVersion
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return version, nil
+}
@@ -0,0 +1,44 @@
+package history
+
+import (
+	"context"
+
+	"apis/payments/services/stripe"
+
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// entityEvents maps the provider events that change an entity's state to the entity type
+var entityEvents = map[stripego.EventType]string{
+	stripego.EventTypeChargeSucceeded: EntityCharge,
+	stripego.EventTypeChargeFailed:    EntityCharge,
+	stripego.EventTypeChargePending:   EntityCharge,
+	stripego.EventTypeChargeCaptured:  EntityCharge,
+	stripego.EventTypeChargeExpired:   EntityCharge,
+	stripego.EventTypeChargeUpdated:   EntityCharge,
+	stripego.EventTypeChargeRefunded:  EntityCharge,
+
+	stripego.EventTypeCustomerSubscriptionCreated:              EntitySubscription,
+	stripego.EventTypeCustomerSubscriptionUpdated:              EntitySubscription,
+	stripego.EventTypeCustomerSubscriptionDeleted:              EntitySubscription,
+	stripego.EventTypeCustomerSubscriptionPaused:               EntitySubscription,
+	stripego.EventTypeCustomerSubscriptionResumed:              EntitySubscription,
+	stripego.EventTypeCustomerSubscriptionPendingUpdateApplied: EntitySubscription,
+	stripego.EventTypeCustomerSubscriptionPendingUpdateExpired: EntitySubscription,
+
+	stripego.EventTypeChargeDisputeCreated:         EntityDispute,
+	stripego.EventTypeChargeDisputeUpdated:         EntityDispute,
+	stripego.EventTypeChargeDisputeClosed:          EntityDispute,
+	stripego.EventTypeChargeDisputeFundsWithdrawn:  EntityDispute,
+	stripego.EventTypeChargeDisputeFundsReinstated: EntityDispute,
+}
+
+// RegisterWebhookHandlers records a version for every charge, subscription
+// and dispute state change
+func (s *Service) RegisterWebhookHandlers(webhooks *stripe.WebhookService) {
+	for eventType, entityType := range entityEvents {
+		webhooks.On(eventType, func(ctx context.Context, event stripego.Event) error {
+			return s.Record(ctx, entityType, event.ID, string(event.Type), event.Created, event.Data.Raw)
+		})
+	}
+}
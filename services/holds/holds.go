@@ -0,0 +1,93 @@
+package holds
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"apis/payments/services/stripe"
+)
+
+// DefaultTenantID is used when a resource carries no tenant information
+const DefaultTenantID = "default"
+
+// Hold reasons
+const (
+	ReasonDispute = "dispute"
+	ReasonFraud   = "fraud"
+)
+
+// Hold statuses
+const (
+	StatusActive   = "active"
+	StatusReleased = "released"
+)
+
+// ErrCustomerOnHold is returned when a charge is blocked by an active hold
+var ErrCustomerOnHold = errors.New("customer is on hold pending review")
+
+// Policy configures how a tenant reacts to disputes and fraud flags
+type Policy struct {
+	TenantID              string    `json:"tenant_id"`
+	PauseOnDispute        bool      `json:"pause_on_dispute"`
+	PauseOnFraud          bool      `json:"pause_on_fraud"`
+	BlockChargesOnDispute bool      `json:"block_charges_on_dispute"`
+	BlockChargesOnFraud   bool      `json:"block_charges_on_fraud"`
+	MinRiskScore          int       `json:"min_risk_score" validate:"min=0,max=100"`
+	AutoRelease           bool      `json:"auto_release"`
+	CreatedAt             time.Time `json:"created_at"`
+	UpdatedAt             time.Time `json:"updated_at"`
+}
+
+// DefaultPolicy returns the policy applied to tenants without explicit configuration
+func DefaultPolicy(tenantID string) *Policy {
+	return &Policy{
+		TenantID:              tenantID,
+		PauseOnDispute:        true,
+		PauseOnFraud:          true,
+		BlockChargesOnDispute: true,
+		BlockChargesOnFraud:   true,
+		MinRiskScore:          75,
+		AutoRelease:           true,
+	}
+}
+
+// Hold represents a customer placed on hold after a dispute or fraud flag
+type Hold struct {
+	ID                  string     `json:"id"`
+	TenantID            string     `json:"tenant_id"`
+	CustomerID          string     `json:"customer_id"`
+	Reason              string     `json:"reason"`
+	SourceID            string     `json:"source_id"` // Dispute, review or fraud warning ID
+	Status              string     `json:"status"`
+	BlocksCharges       bool       `json:"blocks_charges"`
+	PausedSubscriptions []string   `json:"paused_subscriptions,omitempty"`
+	ReleaseReason       string     `json:"release_reason,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+	ReleasedAt          *time.Time `json:"released_at,omitempty"`
+}
+
+// Store persists hold policies and customer holds
+type Store interface {
+	GetHoldPolicy(ctx context.Context, tenantID string) (*Policy, error)
+	UpsertHoldPolicy(ctx context.Context, policy *Policy) (*Policy, error)
+	CreateCustomerHold(ctx context.Context, hold *Hold) (*Hold, error)
+	GetCustomerHold(ctx context.Context, id string) (*Hold, error)
+	GetActiveCustomerHoldBySource(ctx context.Context, sourceID string) (*Hold, error)
+	ListActiveCustomerHolds(ctx context.Context, customerID string) ([]*Hold, error)
+	ListCustomerHolds(ctx context.Context, customerID string) ([]*Hold, error)
+	ReleaseCustomerHold(ctx context.Context, id, reason string) (*Hold, error)
+}
+
+// SubscriptionManager pauses and resumes subscription billing at the provider
+type SubscriptionManager interface {
+	ListActiveSubscriptions(ctx context.Context, customerID string) ([]*stripe.Subscription, error)
+	PauseSubscription(ctx context.Context, subscriptionID string) (*stripe.Subscription, error)
+	UnpauseSubscription(ctx context.Context, subscriptionID string) (*stripe.Subscription, error)
+}
+
+// ChargeLookup resolves the charge behind a dispute or review
+type ChargeLookup interface {
+	GetCharge(ctx context.Context, chargeID string) (*stripe.Charge, error)
+}
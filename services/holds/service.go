@@ -0,0 +1,288 @@
+package holds
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Service applies hold policies when disputes or fraud flags land on a customer
+type Service struct {
+	store         Store
+	subscriptions SubscriptionManager
+	validator     *validator.Validate
+	tracer        trace.Tracer
+}
+
+// NewService creates a new hold service
+func NewService(store Store, subscriptions SubscriptionManager) *Service {
+	return &Service{
+		store:         store,
+		subscriptions: subscriptions,
+		validator:     validator.New(),
+		tracer:        otel.Tracer("payments.holds"),
+	}
+}
+
+// GetPolicy returns the tenant's policy, falling back to the default policy
+func (s *Service) GetPolicy(ctx context.Context, tenantID string) (*Policy, error) {
+	ctx, span := s.tracer.Start(ctx, "GetPolicy")
+	defer span.End()
+
+	if tenantID == "" {
+		tenantID = DefaultTenantID
+	}
+
+	policy, err := s.store.GetHoldPolicy(ctx, tenantID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return DefaultPolicy(tenantID), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hold policy: %w", err)
+	}
+
+	return policy, nil
+}
+
+// UpdatePolicy stores the policy for a tenant
+func (s *Service) UpdatePolicy(ctx context.Context, policy *Policy) (*Policy, error) {
+	ctx, span := s.tracer.Start(ctx, "UpdatePolicy")
+	defer span.End()
+
+	if err := s.validator.Struct(policy); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	if policy.TenantID == "" {
+		return nil, fmt.Errorf("tenant ID cannot be empty")
+	}
+
+	return s.store.UpsertHoldPolicy(ctx, policy)
+}
+
+// HandleDisputeOpened places a customer on hold when a dispute is opened against them
+func (s *Service) HandleDisputeOpened(ctx context.Context, tenantID, customerID, disputeID string) (*Hold, error) {
+	ctx, span := s.tracer.Start(ctx, "HandleDisputeOpened")
+	defer span.End()
+
+	policy, err := s.GetPolicy(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.placeHold(ctx, policy, customerID, ReasonDispute, disputeID, policy.PauseOnDispute, policy.BlockChargesOnDispute)
+}
+
+// HandleDisputeClosed releases the dispute's hold if the dispute was won
+func (s *Service) HandleDisputeClosed(ctx context.Context, tenantID, disputeID, status string) error {
+	ctx, span := s.tracer.Start(ctx, "HandleDisputeClosed")
+	defer span.End()
+
+	if status != "won" {
+		// Lost disputes keep the customer on hold until an operator releases them
+		return nil
+	}
+
+	return s.autoRelease(ctx, tenantID, disputeID, "dispute_won")
+}
+
+// HandleFraudFlag places a customer on hold when a high-risk fraud signal is raised.
+// Signals with a risk score below the policy threshold are ignored unless the
+// provider marked the charge as highest risk.
+func (s *Service) HandleFraudFlag(ctx context.Context, tenantID, customerID, sourceID, riskLevel string, riskScore int64) (*Hold, error) {
+	ctx, span := s.tracer.Start(ctx, "HandleFraudFlag")
+	defer span.End()
+
+	policy, err := s.GetPolicy(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if riskLevel != "highest" && riskScore < int64(policy.MinRiskScore) {
+		return nil, nil
+	}
+
+	return s.placeHold(ctx, policy, customerID, ReasonFraud, sourceID, policy.PauseOnFraud, policy.BlockChargesOnFraud)
+}
+
+// HandleReviewClosed releases the review's hold if the review passed
+func (s *Service) HandleReviewClosed(ctx context.Context, tenantID, reviewID, reason string) error {
+	ctx, span := s.tracer.Start(ctx, "HandleReviewClosed")
+	defer span.End()
+
+	if reason != "approved" {
+		return nil
+	}
+
+	return s.autoRelease(ctx, tenantID, reviewID, "review_passed")
+}
+
+// ReleaseHold releases a hold and unpauses the subscriptions it paused
+func (s *Service) ReleaseHold(ctx context.Context, holdID, reason string) (*Hold, error) {
+	ctx, span := s.tracer.Start(ctx, "ReleaseHold")
+	defer span.End()
+
+	if holdID == "" {
+		return nil, fmt.Errorf("hold ID cannot be empty")
+	}
+
+	hold, err := s.store.GetCustomerHold(ctx, holdID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hold: %w", err)
+	}
+
+	if hold.Status != StatusActive {
+		return nil, fmt.Errorf("hold %s is not active", holdID)
+	}
+
+	return s.release(ctx, hold, reason)
+}
+
+// ListHolds lists all holds for a customer
+func (s *Service) ListHolds(ctx context.Context, customerID string) ([]*Hold, error) {
+	ctx, span := s.tracer.Start(ctx, "ListHolds")
+	defer span.End()
+
+	if customerID == "" {
+		return nil, fmt.Errorf("customer ID cannot be empty")
+	}
+
+	return s.store.ListCustomerHolds(ctx, customerID)
+}
+
+// CheckCharge blocks charges for customers with an active charge-blocking hold
+func (s *Service) CheckCharge(ctx context.Context, customerID string) error {
+	ctx, span := s.tracer.Start(ctx, "CheckCharge")
+	defer span.End()
+
+	active, err := s.store.ListActiveCustomerHolds(ctx, customerID)
+	if err != nil {
+		return fmt.Errorf("failed to check customer holds: %w", err)
+	}
+
+	for _, hold := range active {
+		if hold.BlocksCharges {
+			return fmt.Errorf("%w: %s hold %s", ErrCustomerOnHold, hold.Reason, hold.ID)
+		}
+	}
+
+	return nil
+}
+
+// placeHold records a hold and pauses the customer's active subscriptions.
+// Holds are idempotent per source, so redelivered events don't stack holds.
+func (s *Service) placeHold(ctx context.Context, policy *Policy, customerID, reason, sourceID string, pause, block bool) (*Hold, error) {
+	if customerID == "" {
+		return nil, fmt.Errorf("customer ID cannot be empty")
+	}
+
+	if !pause && !block {
+		return nil, nil
+	}
+
+	existing, err := s.store.GetActiveCustomerHoldBySource(ctx, sourceID)
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to check existing hold: %w", err)
+	}
+
+	var paused []string
+	if pause {
+		subscriptions, err := s.subscriptions.ListActiveSubscriptions(ctx, customerID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list subscriptions: %w", err)
+		}
+
+		for _, sub := range subscriptions {
+			if sub.Paused {
+				continue
+			}
+			if _, err := s.subscriptions.PauseSubscription(ctx, sub.ID); err != nil {
+				log.Printf("Failed to pause subscription %s for customer %s: %v", sub.ID, customerID, err)
+				continue
+			}
+			paused = append(paused, sub.ID)
+		}
+	}
+
+	hold, err := s.store.CreateCustomerHold(ctx, &Hold{
+		ID:                  fmt.Sprintf("hold_%s", uuid.New().String()),
+		TenantID:            policy.TenantID,
+		CustomerID:          customerID,
+		Reason:              reason,
+		SourceID:            sourceID,
+		Status:              StatusActive,
+		BlocksCharges:       block,
+		PausedSubscriptions: paused,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create hold: %w", err)
+	}
+
+	log.Printf("Placed customer %s on %s hold %s (paused %d subscriptions)", customerID, reason, hold.ID, len(paused))
+	return hold, nil
+}
+
+// autoRelease releases the active hold for a source if the tenant allows it
+func (s *Service) autoRelease(ctx context.Context, tenantID, sourceID, reason string) error {
+	policy, err := s.GetPolicy(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+
+	if !policy.AutoRelease {
+		return nil
+	}
+
+	hold, err := s.store.GetActiveCustomerHoldBySource(ctx, sourceID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get hold: %w", err)
+	}
+
+	_, err = s.release(ctx, hold, reason)
+	return err
+}
+
+// release marks a hold as released and unpauses subscriptions that no other
+// active hold still keeps paused
+func (s *Service) release(ctx context.Context, hold *Hold, reason string) (*Hold, error) {
+	released, err := s.store.ReleaseCustomerHold(ctx, hold.ID, reason)
+	if err != nil {
+		return nil, fmt.Errorf("failed to release hold: %w", err)
+	}
+
+	stillHeld := make(map[string]bool)
+	others, err := s.store.ListActiveCustomerHolds(ctx, hold.CustomerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list customer holds: %w", err)
+	}
+	for _, other := range others {
+		for _, subscriptionID := range other.PausedSubscriptions {
+			stillHeld[subscriptionID] = true
+		}
+	}
+
+	for _, subscriptionID := range hold.PausedSubscriptions {
+		if stillHeld[subscriptionID] {
+			continue
+		}
+		if _, err := s.subscriptions.UnpauseSubscription(ctx, subscriptionID); err != nil {
+			log.Printf("Failed to unpause subscription %s for customer %s: %v", subscriptionID, hold.CustomerID, err)
+		}
+	}
+
+	log.Printf("Released %s hold %s for customer %s (%s)", hold.Reason, hold.ID, hold.CustomerID, reason)
+	return released, nil
+}
@@ -0,0 +1,110 @@
+package holds
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"apis/payments/services/stripe"
+
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// RegisterWebhookHandlers subscribes the hold service to dispute and fraud events
+func (s *Service) RegisterWebhookHandlers(webhooks *stripe.WebhookService, charges ChargeLookup) {
+	webhooks.On(stripego.EventTypeChargeDisputeCreated, func(ctx context.Context, event stripego.Event) error {
+		var dispute stripego.Dispute
+		if err := json.Unmarshal(event.Data.Raw, &dispute); err != nil {
+			return fmt.Errorf("failed to parse dispute: %w", err)
+		}
+
+		charge, err := lookupCharge(ctx, charges, dispute.Charge)
+		if err != nil || charge == nil {
+			return err
+		}
+
+		_, err = s.HandleDisputeOpened(ctx, tenantOf(charge), charge.CustomerID, dispute.ID)
+		return err
+	})
+
+	webhooks.On(stripego.EventTypeChargeDisputeClosed, func(ctx context.Context, event stripego.Event) error {
+		var dispute stripego.Dispute
+		if err := json.Unmarshal(event.Data.Raw, &dispute); err != nil {
+			return fmt.Errorf("failed to parse dispute: %w", err)
+		}
+
+		charge, err := lookupCharge(ctx, charges, dispute.Charge)
+		if err != nil {
+			return err
+		}
+
+		return s.HandleDisputeClosed(ctx, tenantOf(charge), dispute.ID, string(dispute.Status))
+	})
+
+	webhooks.On(stripego.EventTypeReviewOpened, func(ctx context.Context, event stripego.Event) error {
+		var review stripego.Review
+		if err := json.Unmarshal(event.Data.Raw, &review); err != nil {
+			return fmt.Errorf("failed to parse review: %w", err)
+		}
+
+		charge, err := lookupCharge(ctx, charges, review.Charge)
+		if err != nil || charge == nil {
+			return err
+		}
+
+		_, err = s.HandleFraudFlag(ctx, tenantOf(charge), charge.CustomerID, review.ID, charge.RiskLevel, charge.RiskScore)
+		return err
+	})
+
+	webhooks.On(stripego.EventTypeReviewClosed, func(ctx context.Context, event stripego.Event) error {
+		var review stripego.Review
+		if err := json.Unmarshal(event.Data.Raw, &review); err != nil {
+			return fmt.Errorf("failed to parse review: %w", err)
+		}
+
+		charge, err := lookupCharge(ctx, charges, review.Charge)
+		if err != nil {
+			return err
+		}
+
+		return s.HandleReviewClosed(ctx, tenantOf(charge), review.ID, string(review.ClosedReason))
+	})
+
+	webhooks.On(stripego.EventTypeRadarEarlyFraudWarningCreated, func(ctx context.Context, event stripego.Event) error {
+		var warning stripego.RadarEarlyFraudWarning
+		if err := json.Unmarshal(event.Data.Raw, &warning); err != nil {
+			return fmt.Errorf("failed to parse early fraud warning: %w", err)
+		}
+
+		charge, err := lookupCharge(ctx, charges, warning.Charge)
+		if err != nil || charge == nil {
+			return err
+		}
+
+		// Early fraud warnings come from the card issuer and are always treated as highest risk
+		_, err = s.HandleFraudFlag(ctx, tenantOf(charge), charge.CustomerID, warning.ID, "highest", charge.RiskScore)
+		return err
+	})
+}
+
+// lookupCharge fetches the full charge referenced by an event object
+func lookupCharge(ctx context.Context, charges ChargeLookup, ref *stripego.Charge) (*stripe.Charge, error) {
+	if ref == nil || ref.ID == "" {
+		return nil, nil
+	}
+
+	charge, err := charges.GetCharge(ctx, ref.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up charge %s: %w", ref.ID, err)
+	}
+
+	return charge, nil
+}
+
+// tenantOf resolves the tenant that owns a charge
+func tenantOf(charge *stripe.Charge) string {
+	if charge != nil && charge.Metadata["tenant_id"] != "" {
+		return charge.Metadata["tenant_id"]
+	}
+	return DefaultTenantID
+}
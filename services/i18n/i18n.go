@@ -0,0 +1,142 @@
+package i18n
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stripe/stripe-go/v76"
+)
+
+// DefaultLanguage is used when the caller accepts none of the supported languages
+const DefaultLanguage = "en"
+
+// Translator maps errors to customer-safe messages in the caller's language
+type Translator struct {
+	messages map[string]map[string]string
+	errors   []registeredError
+}
+
+// registeredError maps a sentinel error to a message key
+type registeredError struct {
+	target error
+	key    string
+}
+
+// NewTranslator creates a translator with the built-in message catalog
+func NewTranslator() *Translator {
+	return &Translator{
+		messages: catalog,
+	}
+}
+
+// Register maps errors matching target (via errors.Is) to a message key
+func (t *Translator) Register(target error, key string) {
+	t.errors = append(t.errors, registeredError{target: target, key: key})
+}
+
+// Languages returns the supported language tags
+func (t *Translator) Languages() []string {
+	languages := make([]string, 0, len(t.messages))
+	for language := range t.messages {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// Negotiate picks the best supported language for an Accept-Language header
+func (t *Translator) Negotiate(acceptLanguage string) string {
+	best, bestQuality := DefaultLanguage, 0.0
+
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err == nil {
+					quality = q
+				}
+			}
+		}
+
+		// Match on the primary subtag, so fr-CA falls back to fr
+		language := strings.SplitN(tag, "-", 2)[0]
+		if _, ok := t.messages[language]; ok && quality > bestQuality {
+			best, bestQuality = language, quality
+		}
+	}
+
+	return best
+}
+
+// Message returns the message for a key in the negotiated language
+func (t *Translator) Message(key, acceptLanguage string) string {
+	language := t.Negotiate(acceptLanguage)
+
+	if message, ok := t.messages[language][key]; ok {
+		return message
+	}
+	if message, ok := t.messages[DefaultLanguage][key]; ok {
+		return message
+	}
+	return t.messages[language][KeyGenericError]
+}
+
+// Localize returns a customer-safe message for an error
+func (t *Translator) Localize(err error, acceptLanguage string) string {
+	return t.Message(t.Key(err), acceptLanguage)
+}
+
+// Key resolves the message key for an error. Provider decline codes take
+// precedence over error codes, and anything unrecognised maps to a generic
+// message so internal details never reach customers.
+func (t *Translator) Key(err error) string {
+	if err == nil {
+		return KeyGenericError
+	}
+
+	for _, registered := range t.errors {
+		if errors.Is(err, registered.target) {
+			return registered.key
+		}
+	}
+
+	var stripeErr *stripe.Error
+	if errors.As(err, &stripeErr) {
+		if key, ok := declineCodes[string(stripeErr.DeclineCode)]; ok {
+			return key
+		}
+		if key, ok := declineCodes[string(stripeErr.Code)]; ok {
+			return key
+		}
+		if stripeErr.Type == stripe.ErrorTypeCard {
+			return KeyCardDeclined
+		}
+		return KeyGenericError
+	}
+
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) && len(validationErrs) > 0 {
+		fieldErr := validationErrs[0]
+		switch {
+		case fieldErr.Tag() == "required":
+			return KeyMissingParameter
+		case fieldErr.Tag() == "email":
+			return KeyInvalidEmail
+		case fieldErr.Field() == "Amount":
+			return KeyInvalidAmount
+		}
+		return KeyValidationFailed
+	}
+
+	return KeyGenericError
+}
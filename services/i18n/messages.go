@@ -0,0 +1,211 @@
+package i18n
+
+// Message keys
+const (
+	KeyGenericError         = "generic_error"
+	KeyInvalidRequest       = "invalid_request"
+	KeyMissingParameter     = "missing_parameter"
+	KeyValidationFailed     = "validation_failed"
+	KeyInvalidEmail         = "invalid_email"
+	KeyNotFound             = "not_found"
+	KeyCardDeclined         = "card_declined"
+	KeyInsufficientFunds    = "insufficient_funds"
+	KeyExpiredCard          = "expired_card"
+	KeyIncorrectCVC         = "incorrect_cvc"
+	KeyIncorrectNumber      = "incorrect_number"
+	KeyIncorrectPIN         = "incorrect_pin"
+	KeyIncorrectZip         = "incorrect_zip"
+	KeyInvalidExpiry        = "invalid_expiry"
+	KeyCardNotSupported     = "card_not_supported"
+	KeyCurrencyNotSupported = "currency_not_supported"
+	KeyTryAgainLater        = "try_again_later"
+	KeyAuthenticationNeeded = "authentication_required"
+	KeyCardVelocityExceeded = "card_velocity_exceeded"
+	KeyDuplicateTransaction = "duplicate_transaction"
+	KeyCallIssuer           = "call_issuer"
+	KeyInvalidAmount        = "invalid_amount"
+	KeyAlreadyRefunded      = "already_refunded"
+	KeyAccountOnHold        = "account_on_hold"
+	KeyNotPermitted         = "not_permitted"
+	KeyUnauthorized         = "unauthorized"
+	KeyDuplicateCustomer    = "duplicate_customer"
+)
+
+// declineCodes maps provider decline and error codes to message keys.
+// Codes that reveal why an issuer declined (lost, stolen, fraudulent cards)
+// deliberately map to the generic decline message.
+var declineCodes = map[string]string{
+	"card_declined":                     KeyCardDeclined,
+	"generic_decline":                   KeyCardDeclined,
+	"do_not_honor":                      KeyCardDeclined,
+	"do_not_try_again":                  KeyCardDeclined,
+	"fraudulent":                        KeyCardDeclined,
+	"lost_card":                         KeyCardDeclined,
+	"stolen_card":                       KeyCardDeclined,
+	"pickup_card":                       KeyCardDeclined,
+	"restricted_card":                   KeyCardDeclined,
+	"security_violation":                KeyCardDeclined,
+	"merchant_blacklist":                KeyCardDeclined,
+	"no_action_taken":                   KeyCardDeclined,
+	"revocation_of_all_authorizations":  KeyCardDeclined,
+	"revocation_of_authorization":       KeyCardDeclined,
+	"stop_payment_order":                KeyCardDeclined,
+	"new_account_information_available": KeyCardDeclined,
+	"invalid_account":                   KeyCardDeclined,
+	"testmode_decline":                  KeyCardDeclined,
+	"insufficient_funds":                KeyInsufficientFunds,
+	"expired_card":                      KeyExpiredCard,
+	"incorrect_cvc":                     KeyIncorrectCVC,
+	"invalid_cvc":                       KeyIncorrectCVC,
+	"incorrect_number":                  KeyIncorrectNumber,
+	"invalid_number":                    KeyIncorrectNumber,
+	"incorrect_pin":                     KeyIncorrectPIN,
+	"invalid_pin":                       KeyIncorrectPIN,
+	"pin_try_exceeded":                  KeyIncorrectPIN,
+	"offline_pin_required":              KeyIncorrectPIN,
+	"online_or_offline_pin_required":    KeyIncorrectPIN,
+	"incorrect_zip":                     KeyIncorrectZip,
+	"invalid_expiry_month":              KeyInvalidExpiry,
+	"invalid_expiry_year":               KeyInvalidExpiry,
+	"card_not_supported":                KeyCardNotSupported,
+	"transaction_not_allowed":           KeyCardNotSupported,
+	"service_not_allowed":               KeyCardNotSupported,
+	"not_permitted":                     KeyCardNotSupported,
+	"currency_not_supported":            KeyCurrencyNotSupported,
+	"issuer_not_available":              KeyTryAgainLater,
+	"processing_error":                  KeyTryAgainLater,
+	"reenter_transaction":               KeyTryAgainLater,
+	"try_again_later":                   KeyTryAgainLater,
+	"rate_limit":                        KeyTryAgainLater,
+	"authentication_required":           KeyAuthenticationNeeded,
+	"card_velocity_exceeded":            KeyCardVelocityExceeded,
+	"withdrawal_count_limit_exceeded":   KeyCardVelocityExceeded,
+	"duplicate_transaction":             KeyDuplicateTransaction,
+	"call_issuer":                       KeyCallIssuer,
+	"approve_with_id":                   KeyCallIssuer,
+	"invalid_amount":                    KeyInvalidAmount,
+	"amount_too_small":                  KeyInvalidAmount,
+	"amount_too_large":                  KeyInvalidAmount,
+	"charge_already_refunded":           KeyAlreadyRefunded,
+	"resource_missing":                  KeyNotFound,
+}
+
+// catalog holds the customer-facing messages for each supported language
+var catalog = map[string]map[string]string{
+	"en": {
+		KeyGenericError:         "Something went wrong. Please try again.",
+		KeyInvalidRequest:       "We couldn't process your request. Please check your details and try again.",
+		KeyMissingParameter:     "Some required information is missing.",
+		KeyValidationFailed:     "Some of the information provided is invalid.",
+		KeyInvalidEmail:         "Please enter a valid email address.",
+		KeyNotFound:             "We couldn't find what you were looking for.",
+		KeyCardDeclined:         "Your card was declined.",
+		KeyInsufficientFunds:    "Your card has insufficient funds.",
+		KeyExpiredCard:          "Your card has expired.",
+		KeyIncorrectCVC:         "Your card's security code is incorrect.",
+		KeyIncorrectNumber:      "Your card number is incorrect.",
+		KeyIncorrectPIN:         "The PIN entered is incorrect.",
+		KeyIncorrectZip:         "Your postal code is incorrect.",
+		KeyInvalidExpiry:        "Your card's expiration date is invalid.",
+		KeyCardNotSupported:     "Your card does not support this type of purchase.",
+		KeyCurrencyNotSupported: "Your card does not support this currency.",
+		KeyTryAgainLater:        "We couldn't process your payment right now. Please try again later.",
+		KeyAuthenticationNeeded: "Your bank needs you to authenticate this payment.",
+		KeyCardVelocityExceeded: "You have exceeded the limit on your card. Please contact your bank.",
+		KeyDuplicateTransaction: "This payment looks like a duplicate of one you just made.",
+		KeyCallIssuer:           "Your card was declined. Please contact your bank for details.",
+		KeyInvalidAmount:        "The payment amount is invalid.",
+		KeyAlreadyRefunded:      "This payment has already been refunded.",
+		KeyAccountOnHold:        "Payments on this account are temporarily on hold. Please contact support.",
+		KeyNotPermitted:         "You are not permitted to perform this action.",
+		KeyUnauthorized:         "Authentication is required. Provide a valid API key.",
+		KeyDuplicateCustomer:    "A customer with this email and name already exists.",
+	},
+	"es": {
+		KeyGenericError:         "Algo salió mal. Inténtalo de nuevo.",
+		KeyInvalidRequest:       "No pudimos procesar tu solicitud. Revisa tus datos e inténtalo de nuevo.",
+		KeyMissingParameter:     "Falta información obligatoria.",
+		KeyValidationFailed:     "Parte de la información proporcionada no es válida.",
+		KeyInvalidEmail:         "Introduce una dirección de correo electrónico válida.",
+		KeyNotFound:             "No encontramos lo que buscabas.",
+		KeyCardDeclined:         "Tu tarjeta fue rechazada.",
+		KeyInsufficientFunds:    "Tu tarjeta no tiene fondos suficientes.",
+		KeyExpiredCard:          "Tu tarjeta ha caducado.",
+		KeyIncorrectCVC:         "El código de seguridad de tu tarjeta es incorrecto.",
+		KeyIncorrectNumber:      "El número de tu tarjeta es incorrecto.",
+		KeyIncorrectPIN:         "El PIN introducido es incorrecto.",
+		KeyIncorrectZip:         "Tu código postal es incorrecto.",
+		KeyInvalidExpiry:        "La fecha de caducidad de tu tarjeta no es válida.",
+		KeyCardNotSupported:     "Tu tarjeta no admite este tipo de compra.",
+		KeyCurrencyNotSupported: "Tu tarjeta no admite esta moneda.",
+		KeyTryAgainLater:        "No pudimos procesar tu pago en este momento. Inténtalo más tarde.",
+		KeyAuthenticationNeeded: "Tu banco necesita que autentiques este pago.",
+		KeyCardVelocityExceeded: "Has superado el límite de tu tarjeta. Ponte en contacto con tu banco.",
+		KeyDuplicateTransaction: "Este pago parece un duplicado de uno que acabas de realizar.",
+		KeyCallIssuer:           "Tu tarjeta fue rechazada. Ponte en contacto con tu banco para más detalles.",
+		KeyInvalidAmount:        "El importe del pago no es válido.",
+		KeyAlreadyRefunded:      "Este pago ya ha sido reembolsado.",
+		KeyAccountOnHold:        "Los pagos de esta cuenta están suspendidos temporalmente. Ponte en contacto con soporte.",
+		KeyNotPermitted:         "No tienes permiso para realizar esta acción.",
+		KeyUnauthorized:         "Se requiere autenticación. Proporciona una clave de API válida.",
+		KeyDuplicateCustomer:    "Ya existe un cliente con este correo electrónico y nombre.",
+	},
+	"fr": {
+		KeyGenericError:         "Une erreur s'est produite. Veuillez réessayer.",
+		KeyInvalidRequest:       "Nous n'avons pas pu traiter votre demande. Veuillez vérifier vos informations et réessayer.",
+		KeyMissingParameter:     "Des informations obligatoires sont manquantes.",
+		KeyValidationFailed:     "Certaines des informations fournies ne sont pas valides.",
+		KeyInvalidEmail:         "Veuillez saisir une adresse e-mail valide.",
+		KeyNotFound:             "Nous n'avons pas trouvé ce que vous cherchiez.",
+		KeyCardDeclined:         "Votre carte a été refusée.",
+		KeyInsufficientFunds:    "Les fonds de votre carte sont insuffisants.",
+		KeyExpiredCard:          "Votre carte a expiré.",
+		KeyIncorrectCVC:         "Le code de sécurité de votre carte est incorrect.",
+		KeyIncorrectNumber:      "Le numéro de votre carte est incorrect.",
+		KeyIncorrectPIN:         "Le code PIN saisi est incorrect.",
+		KeyIncorrectZip:         "Votre code postal est incorrect.",
+		KeyInvalidExpiry:        "La date d'expiration de votre carte n'est pas valide.",
+		KeyCardNotSupported:     "Votre carte ne prend pas en charge ce type d'achat.",
+		KeyCurrencyNotSupported: "Votre carte ne prend pas en charge cette devise.",
+		KeyTryAgainLater:        "Nous n'avons pas pu traiter votre paiement pour le moment. Veuillez réessayer plus tard.",
+		KeyAuthenticationNeeded: "Votre banque vous demande d'authentifier ce paiement.",
+		KeyCardVelocityExceeded: "Vous avez dépassé le plafond de votre carte. Veuillez contacter votre banque.",
+		KeyDuplicateTransaction: "Ce paiement semble être un doublon d'un paiement que vous venez d'effectuer.",
+		KeyCallIssuer:           "Votre carte a été refusée. Veuillez contacter votre banque pour plus de détails.",
+		KeyInvalidAmount:        "Le montant du paiement n'est pas valide.",
+		KeyAlreadyRefunded:      "Ce paiement a déjà été remboursé.",
+		KeyAccountOnHold:        "Les paiements sur ce compte sont temporairement suspendus. Veuillez contacter le support.",
+		KeyNotPermitted:         "Vous n'êtes pas autorisé à effectuer cette action.",
+		KeyUnauthorized:         "Une authentification est requise. Fournissez une clé d'API valide.",
+		KeyDuplicateCustomer:    "Un client avec cette adresse e-mail et ce nom existe déjà.",
+	},
+	"de": {
+		KeyGenericError:         "Etwas ist schiefgelaufen. Bitte versuchen Sie es erneut.",
+		KeyInvalidRequest:       "Ihre Anfrage konnte nicht verarbeitet werden. Bitte prüfen Sie Ihre Angaben und versuchen Sie es erneut.",
+		KeyMissingParameter:     "Erforderliche Angaben fehlen.",
+		KeyValidationFailed:     "Einige der angegebenen Informationen sind ungültig.",
+		KeyInvalidEmail:         "Bitte geben Sie eine gültige E-Mail-Adresse ein.",
+		KeyNotFound:             "Wir konnten nicht finden, wonach Sie gesucht haben.",
+		KeyCardDeclined:         "Ihre Karte wurde abgelehnt.",
+		KeyInsufficientFunds:    "Ihre Karte ist nicht ausreichend gedeckt.",
+		KeyExpiredCard:          "Ihre Karte ist abgelaufen.",
+		KeyIncorrectCVC:         "Der Sicherheitscode Ihrer Karte ist falsch.",
+		KeyIncorrectNumber:      "Ihre Kartennummer ist falsch.",
+		KeyIncorrectPIN:         "Die eingegebene PIN ist falsch.",
+		KeyIncorrectZip:         "Ihre Postleitzahl ist falsch.",
+		KeyInvalidExpiry:        "Das Ablaufdatum Ihrer Karte ist ungültig.",
+		KeyCardNotSupported:     "Ihre Karte unterstützt diese Art von Kauf nicht.",
+		KeyCurrencyNotSupported: "Ihre Karte unterstützt diese Währung nicht.",
+		KeyTryAgainLater:        "Ihre Zahlung konnte gerade nicht verarbeitet werden. Bitte versuchen Sie es später erneut.",
+		KeyAuthenticationNeeded: "Ihre Bank verlangt eine Authentifizierung dieser Zahlung.",
+		KeyCardVelocityExceeded: "Sie haben das Limit Ihrer Karte überschritten. Bitte wenden Sie sich an Ihre Bank.",
+		KeyDuplicateTransaction: "Diese Zahlung scheint ein Duplikat einer gerade getätigten Zahlung zu sein.",
+		KeyCallIssuer:           "Ihre Karte wurde abgelehnt. Bitte wenden Sie sich für Details an Ihre Bank.",
+		KeyInvalidAmount:        "Der Zahlungsbetrag ist ungültig.",
+		KeyAlreadyRefunded:      "Diese Zahlung wurde bereits erstattet.",
+		KeyAccountOnHold:        "Zahlungen auf diesem Konto sind vorübergehend ausgesetzt. Bitte wenden Sie sich an den Support.",
+		KeyNotPermitted:         "Sie sind nicht berechtigt, diese Aktion auszuführen.",
+		KeyUnauthorized:         "Eine Authentifizierung ist erforderlich. Bitte geben Sie einen gültigen API-Schlüssel an.",
+		KeyDuplicateCustomer:    "Ein Kunde mit dieser E-Mail-Adresse und diesem Namen existiert bereits.",
+	},
+}
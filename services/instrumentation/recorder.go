@@ -0,0 +1,145 @@
+package instrumentation
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// samplesPerOperation bounds the latency samples kept per operation, so
+// percentiles reflect recent traffic
+const samplesPerOperation = 1024
+
+// Call outcomes
+const (
+	OutcomeSuccess     = "success"
+	OutcomeClientError = "client_error"
+	OutcomeServerError = "server_error"
+	OutcomeNetworkErr  = "network_error"
+)
+
+// Call describes a single provider call
+type Call struct {
+	Provider   string        `json:"provider"`
+	Operation  string        `json:"operation"`
+	Duration   time.Duration `json:"duration"`
+	Outcome    string        `json:"outcome"`
+	StatusCode int           `json:"status_code,omitempty"`
+	RequestID  string        `json:"request_id,omitempty"`
+}
+
+// OperationStats summarises recent latency for one provider operation
+type OperationStats struct {
+	Provider  string  `json:"provider"`
+	Operation string  `json:"operation"`
+	Count     int64   `json:"count"`
+	Errors    int64   `json:"errors"`
+	Slow      int64   `json:"slow"`
+	P50Ms     float64 `json:"p50_ms"`
+	P90Ms     float64 `json:"p90_ms"`
+	P99Ms     float64 `json:"p99_ms"`
+	MaxMs     float64 `json:"max_ms"`
+}
+
+// operationSamples holds the rolling samples for one operation
+type operationSamples struct {
+	count   int64
+	errors  int64
+	slow    int64
+	samples []time.Duration
+	next    int
+}
+
+// Recorder keeps per-operation latency samples for provider calls
+type Recorder struct {
+	mu         sync.Mutex
+	operations map[string]*operationSamples
+	keys       map[string][2]string
+}
+
+// NewRecorder creates an empty recorder
+func NewRecorder() *Recorder {
+	return &Recorder{
+		operations: make(map[string]*operationSamples),
+		keys:       make(map[string][2]string),
+	}
+}
+
+// Record adds a call to the operation's samples
+func (r *Recorder) Record(call Call, slow bool) {
+	key := call.Provider + " " + call.Operation
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ops, ok := r.operations[key]
+	if !ok {
+		ops = &operationSamples{samples: make([]time.Duration, 0, samplesPerOperation)}
+		r.operations[key] = ops
+		r.keys[key] = [2]string{call.Provider, call.Operation}
+	}
+
+	ops.count++
+	if call.Outcome != OutcomeSuccess {
+		ops.errors++
+	}
+	if slow {
+		ops.slow++
+	}
+
+	if len(ops.samples) < samplesPerOperation {
+		ops.samples = append(ops.samples, call.Duration)
+	} else {
+		ops.samples[ops.next] = call.Duration
+		ops.next = (ops.next + 1) % samplesPerOperation
+	}
+}
+
+// Snapshot returns latency percentiles for every operation, slowest p99 first
+func (r *Recorder) Snapshot() []OperationStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := make([]OperationStats, 0, len(r.operations))
+	for key, ops := range r.operations {
+		sorted := append([]time.Duration(nil), ops.samples...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+		stats = append(stats, OperationStats{
+			Provider:  r.keys[key][0],
+			Operation: r.keys[key][1],
+			Count:     ops.count,
+			Errors:    ops.errors,
+			Slow:      ops.slow,
+			P50Ms:     millis(percentile(sorted, 0.50)),
+			P90Ms:     millis(percentile(sorted, 0.90)),
+			P99Ms:     millis(percentile(sorted, 0.99)),
+			MaxMs:     millis(percentile(sorted, 1)),
+		})
+	}
+
+	sort.Slice(stats, func(i, j int) bool { return stats[i].P99Ms > stats[j].P99Ms })
+	return stats
+}
+
+// percentile returns the nearest-rank percentile of sorted samples
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := int(p*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+
+	return sorted[rank]
+}
+
+// millis converts a duration to fractional milliseconds
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,111 @@
+package instrumentation
+
+import (
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// providerID matches path segments that are provider object IDs such as
+// ch_3MqL0a2eZvKYlo2C, as opposed to resource names such as payment_methods
+var providerID = regexp.MustCompile(`^[a-z]+_[A-Za-z0-9]*[A-Z0-9][A-Za-z0-9]*$`)
+
+// Transport is an http.RoundTripper that measures every provider call,
+// records its latency and logs calls slower than the threshold
+type Transport struct {
+	base          http.RoundTripper
+	provider      string
+	recorder      *Recorder
+	slowThreshold time.Duration
+	tracer        trace.Tracer
+}
+
+// NewTransport wraps base (http.DefaultTransport if nil) for the given provider
+func NewTransport(base http.RoundTripper, provider string, recorder *Recorder, slowThreshold time.Duration) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &Transport{
+		base:          base,
+		provider:      provider,
+		recorder:      recorder,
+		slowThreshold: slowThreshold,
+		tracer:        otel.Tracer("payments.gateway"),
+	}
+}
+
+// RoundTrip performs the request and records the call
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	operation := Operation(req.Method, req.URL.Path)
+
+	ctx, span := t.tracer.Start(req.Context(), "gateway "+operation, trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	duration := time.Since(start)
+
+	call := Call{
+		Provider:  t.provider,
+		Operation: operation,
+		Duration:  duration,
+	}
+
+	switch {
+	case err != nil:
+		call.Outcome = OutcomeNetworkErr
+	case resp.StatusCode >= 500:
+		call.Outcome = OutcomeServerError
+	case resp.StatusCode >= 400:
+		call.Outcome = OutcomeClientError
+	default:
+		call.Outcome = OutcomeSuccess
+	}
+	if resp != nil {
+		call.StatusCode = resp.StatusCode
+		call.RequestID = resp.Header.Get("Request-Id")
+	}
+
+	span.SetAttributes(
+		attribute.String("gateway.provider", call.Provider),
+		attribute.String("gateway.operation", call.Operation),
+		attribute.String("gateway.outcome", call.Outcome),
+		attribute.Int("http.status_code", call.StatusCode),
+		attribute.String("gateway.request_id", call.RequestID),
+	)
+	if call.Outcome != OutcomeSuccess {
+		span.SetStatus(codes.Error, call.Outcome)
+	}
+
+	slow := t.slowThreshold > 0 && duration >= t.slowThreshold
+	if slow {
+		log.Printf("Slow gateway call: provider=%s operation=%q duration=%s outcome=%s status=%d request_id=%s",
+			call.Provider, call.Operation, duration.Round(time.Millisecond), call.Outcome, call.StatusCode, call.RequestID)
+	}
+
+	if t.recorder != nil {
+		t.recorder.Record(call, slow)
+	}
+
+	return resp, err
+}
+
+// Operation names a provider call by method and path, with object IDs
+// replaced so calls on different objects share an operation
+func Operation(method, path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if providerID.MatchString(segment) {
+			segments[i] = ":id"
+		}
+	}
+	return method + " " + strings.Join(segments, "/")
+}
@@ -30,10 +30,35 @@ type GatewayCapabilities struct {
 	SupportsDisputes      bool
 	SupportsConnect       bool
 	SupportsTax           bool
+	SupportsInvoices      bool
+	SupportsPaymentLinks  bool
 	MaxChargeAmount       int64  // in cents
 	MinChargeAmount       int64  // in cents
 	SupportedCurrencies   []string
 	SupportedCountries    []string
+	BNPLMethods           []BNPLMethod  // buy-now-pay-later methods, none when empty
+	SupportsMultiCapture  bool          // an authorization can be captured in several parts
+	AuthorizationWindow   time.Duration // how long a card authorization stays capturable, zero without authorize-only charges
+}
+
+// BNPLMethod describes a buy-now-pay-later payment method a gateway accepts.
+// The customer authorizes the payment by being redirected to the lender, and
+// an authorization must be captured within CaptureWindow.
+type BNPLMethod struct {
+	Type          string        // payment method type, e.g. klarna
+	CaptureWindow time.Duration // how long an authorization can be captured
+	Currencies    []string      // currencies the lender accepts
+}
+
+// BNPLMethod returns the buy-now-pay-later method of the given type, if the
+// gateway accepts it in the currency
+func (c GatewayCapabilities) BNPLMethod(methodType, currency string) (BNPLMethod, bool) {
+	for _, method := range c.BNPLMethods {
+		if method.Type == methodType && listed(method.Currencies, currency) {
+			return method, true
+		}
+	}
+	return BNPLMethod{}, false
 }
 
 // CustomerVault defines customer management operations
@@ -60,7 +85,7 @@ type CustomerVault interface {
 	RemovePaymentMethod(ctx context.Context, customerID string, paymentMethodID string) error
 	
 	// ListPaymentMethods lists payment methods for a customer
-	ListPaymentMethods(ctx context.Context, customerID string) ([]*PaymentMethod, error)
+	ListPaymentMethods(ctx context.Context, customerID string, req ListPaymentMethodsRequest) (*PaymentMethodList, error)
 }
 
 // PaymentProcessor defines payment processing operations
@@ -77,6 +102,9 @@ type PaymentProcessor interface {
 	// CaptureCharge captures a previously authorized charge
 	CaptureCharge(ctx context.Context, chargeID string, req CaptureChargeRequest) (*Charge, error)
 	
+	// VoidCharge releases an authorization without capturing it
+	VoidCharge(ctx context.Context, chargeID string) (*Charge, error)
+	
 	// ListCharges lists charges with optional filtering
 	ListCharges(ctx context.Context, req ListChargesRequest) (*ChargeList, error)
 }
@@ -114,6 +142,85 @@ type SubscriptionManager interface {
 	ListSubscriptions(ctx context.Context, req ListSubscriptionsRequest) (*SubscriptionList, error)
 }
 
+// DisputeManager defines dispute operations (optional). Gateways whose
+// capabilities report SupportsDisputes implement it.
+type DisputeManager interface {
+	// GetDispute retrieves a dispute by ID
+	GetDispute(ctx context.Context, disputeID string) (*Dispute, error)
+	
+	// ListDisputes lists disputes with optional filtering
+	ListDisputes(ctx context.Context, req ListDisputesRequest) (*DisputeList, error)
+	
+	// AcceptDispute concedes a dispute to the cardholder
+	AcceptDispute(ctx context.Context, disputeID string) (*Dispute, error)
+	
+	// SubmitDisputeEvidence adds evidence to a dispute, submitting it to the bank if requested
+	SubmitDisputeEvidence(ctx context.Context, disputeID string, req SubmitDisputeEvidenceRequest) (*Dispute, error)
+}
+
+// InvoiceGateway defines invoice operations (optional). Gateways whose
+// capabilities report SupportsInvoices implement it.
+type InvoiceGateway interface {
+	// CreateInvoice creates a draft invoice from line items
+	CreateInvoice(ctx context.Context, req CreateInvoiceRequest) (*Invoice, error)
+	
+	// GetInvoice retrieves an invoice by ID
+	GetInvoice(ctx context.Context, invoiceID string) (*Invoice, error)
+	
+	// FinalizeInvoice finalizes a draft invoice so it can be paid
+	FinalizeInvoice(ctx context.Context, invoiceID string) (*Invoice, error)
+	
+	// PayInvoice pays an open invoice now
+	PayInvoice(ctx context.Context, invoiceID string, req PayInvoiceRequest) (*Invoice, error)
+	
+	// VoidInvoice voids an open invoice
+	VoidInvoice(ctx context.Context, invoiceID string) (*Invoice, error)
+	
+	// ListInvoices lists invoices with optional filtering
+	ListInvoices(ctx context.Context, req ListInvoicesRequest) (*InvoiceList, error)
+	
+	// GetInvoicePDFURL returns where a finalized invoice's PDF can be downloaded
+	GetInvoicePDFURL(ctx context.Context, invoiceID string) (string, error)
+}
+
+// ConnectGateway defines marketplace operations (optional). Gateways whose
+// capabilities report SupportsConnect implement it.
+type ConnectGateway interface {
+	// CreateConnectedAccount creates an account for a marketplace seller
+	CreateConnectedAccount(ctx context.Context, req CreateConnectedAccountRequest) (*ConnectedAccount, error)
+	
+	// GetConnectedAccount retrieves a connected account by ID
+	GetConnectedAccount(ctx context.Context, accountID string) (*ConnectedAccount, error)
+	
+	// CreateOnboardingLink returns a link to provider-hosted onboarding for a connected account
+	CreateOnboardingLink(ctx context.Context, accountID string, req CreateOnboardingLinkRequest) (*OnboardingLink, error)
+	
+	// CreateDestinationCharge charges a customer on behalf of a connected account, keeping an application fee
+	CreateDestinationCharge(ctx context.Context, accountID string, req CreateDestinationChargeRequest) (*Charge, error)
+	
+	// CreateTransfer moves funds from the platform balance to a connected account
+	CreateTransfer(ctx context.Context, req CreateTransferRequest) (*Transfer, error)
+	
+	// ListTransfers lists transfers with optional filtering
+	ListTransfers(ctx context.Context, req ListTransfersRequest) (*TransferList, error)
+}
+
+// PaymentLinkGateway defines payment link operations (optional). Gateways
+// whose capabilities report SupportsPaymentLinks implement it.
+type PaymentLinkGateway interface {
+	// CreatePaymentLink creates a shareable payment URL for a fixed amount of one product
+	CreatePaymentLink(ctx context.Context, req CreatePaymentLinkRequest) (*PaymentLink, error)
+	
+	// GetPaymentLink retrieves a payment link by ID
+	GetPaymentLink(ctx context.Context, linkID string) (*PaymentLink, error)
+	
+	// ListPaymentLinks lists payment links with optional filtering
+	ListPaymentLinks(ctx context.Context, req ListPaymentLinksRequest) (*PaymentLinkList, error)
+	
+	// DeactivatePaymentLink stops a payment link from accepting payments
+	DeactivatePaymentLink(ctx context.Context, linkID string) (*PaymentLink, error)
+}
+
 // Common data structures
 
 // Customer represents a customer in the payment system
@@ -184,6 +291,10 @@ type Charge struct {
 	Metadata        map[string]interface{} `json:"metadata,omitempty"`
 	CreatedAt       time.Time              `json:"created_at"`
 	UpdatedAt       time.Time              `json:"updated_at"`
+	CaptureBefore   *time.Time             `json:"capture_before,omitempty"` // when an uncaptured authorization lapses, if the provider reports it
+	StatementDescriptor string             `json:"statement_descriptor,omitempty"`
+	ReceiptEmail        string             `json:"receipt_email,omitempty"`
+	ReceiptURL          string             `json:"receipt_url,omitempty"` // the provider's hosted receipt, if it has one
 	ProviderID      string                 `json:"provider_id"`
 	Provider        string                 `json:"provider"`
 }
@@ -218,6 +329,93 @@ type Subscription struct {
 	Provider     string                 `json:"provider"`
 }
 
+// Dispute represents a chargeback or inquiry raised by the cardholder's bank
+type Dispute struct {
+	ID            string    `json:"id"`
+	ChargeID      string    `json:"charge_id"`
+	Amount        int64     `json:"amount"` // in cents
+	Currency      string    `json:"currency"`
+	Reason        string    `json:"reason"`
+	Status        string    `json:"status"`
+	EvidenceDueBy time.Time `json:"evidence_due_by,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	ProviderID    string    `json:"provider_id"`
+	Provider      string    `json:"provider"`
+}
+
+// Invoice represents a bill for a customer, paid automatically or on receipt
+type Invoice struct {
+	ID               string                 `json:"id"`
+	CustomerID       string                 `json:"customer_id"`
+	SubscriptionID   string                 `json:"subscription_id,omitempty"`
+	Number           string                 `json:"number,omitempty"`
+	Status           string                 `json:"status"` // draft, open, paid, void or uncollectible
+	CollectionMethod string                 `json:"collection_method"`
+	Description      string                 `json:"description,omitempty"`
+	AmountDue        int64                  `json:"amount_due"` // in cents
+	AmountPaid       int64                  `json:"amount_paid"`
+	AmountRemaining  int64                  `json:"amount_remaining"`
+	Currency         string                 `json:"currency"`
+	DueDate          *time.Time             `json:"due_date,omitempty"`
+	HostedURL        string                 `json:"hosted_url,omitempty"`
+	PDFURL           string                 `json:"pdf_url,omitempty"`
+	Metadata         map[string]interface{} `json:"metadata"`
+	CreatedAt        time.Time              `json:"created_at"`
+	ProviderID       string                 `json:"provider_id"`
+	Provider         string                 `json:"provider"`
+}
+
+// ConnectedAccount represents a marketplace seller's account at the provider
+type ConnectedAccount struct {
+	ID               string                 `json:"id"`
+	Type             string                 `json:"type"` // express, standard or custom
+	Email            string                 `json:"email,omitempty"`
+	Country          string                 `json:"country"`
+	ChargesEnabled   bool                   `json:"charges_enabled"`
+	PayoutsEnabled   bool                   `json:"payouts_enabled"`
+	DetailsSubmitted bool                   `json:"details_submitted"`
+	Requirements     []string               `json:"requirements,omitempty"` // onboarding requirements still outstanding
+	Metadata         map[string]interface{} `json:"metadata,omitempty"`
+	CreatedAt        time.Time              `json:"created_at"`
+	ProviderID       string                 `json:"provider_id"`
+	Provider         string                 `json:"provider"`
+}
+
+// OnboardingLink is a short-lived link to provider-hosted onboarding
+type OnboardingLink struct {
+	AccountID string    `json:"account_id"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Transfer represents funds moved from the platform to a connected account
+type Transfer struct {
+	ID             string                 `json:"id"`
+	AccountID      string                 `json:"account_id"`
+	Amount         int64                  `json:"amount"` // in cents
+	Currency       string                 `json:"currency"`
+	SourceChargeID string                 `json:"source_charge_id,omitempty"`
+	TransferGroup  string                 `json:"transfer_group,omitempty"`
+	Reversed       bool                   `json:"reversed"`
+	Metadata       map[string]interface{} `json:"metadata,omitempty"`
+	CreatedAt      time.Time              `json:"created_at"`
+	ProviderID     string                 `json:"provider_id"`
+	Provider       string                 `json:"provider"`
+}
+
+// PaymentLink represents a shareable URL customers pay through
+type PaymentLink struct {
+	ID         string                 `json:"id"`
+	URL        string                 `json:"url"`
+	Active     bool                   `json:"active"`
+	Currency   string                 `json:"currency"`
+	MaxUses    int64                  `json:"max_uses,omitempty"` // payments after which the link deactivates, 0 for unlimited
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+	ProviderID string                 `json:"provider_id"`
+	Provider   string                 `json:"provider"`
+}
+
 // Request/Response structures
 
 type CreateCustomerRequest struct {
@@ -236,16 +434,32 @@ type UpdateCustomerRequest struct {
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
 }
 
+// List requests return a page of at most Limit objects. Cursor continues a
+// list where a previous page ended: pass its NextCursor, which is set
+// whenever HasMore is.
+
 type ListCustomersRequest struct {
 	Limit  int    `json:"limit,omitempty"`
-	Offset int    `json:"offset,omitempty"`
+	Cursor string `json:"cursor,omitempty"`
 	Email  string `json:"email,omitempty"`
 }
 
 type CustomerList struct {
-	Customers []*Customer `json:"customers"`
-	Total     int         `json:"total"`
-	HasMore   bool        `json:"has_more"`
+	Customers  []*Customer `json:"customers"`
+	Total      int         `json:"total"`
+	HasMore    bool        `json:"has_more"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+type ListPaymentMethodsRequest struct {
+	Limit  int    `json:"limit,omitempty"`
+	Cursor string `json:"cursor,omitempty"`
+}
+
+type PaymentMethodList struct {
+	PaymentMethods []*PaymentMethod `json:"payment_methods"`
+	HasMore        bool             `json:"has_more"`
+	NextCursor     string           `json:"next_cursor,omitempty"`
 }
 
 type AddPaymentMethodRequest struct {
@@ -263,6 +477,11 @@ type CreateChargeRequest struct {
 	Description     string                 `json:"description,omitempty"`
 	Metadata        map[string]interface{} `json:"metadata,omitempty"`
 	Capture         bool                   `json:"capture"` // true for immediate capture, false for authorization only
+	MultiCapture    bool                   `json:"multi_capture,omitempty"` // allow capturing an authorization in several parts, where the provider can
+	Country         string                 `json:"country,omitempty"` // issuing country of the payment method, for failover routing
+	StatementDescriptor       string `json:"statement_descriptor,omitempty"`        // replaces the account's descriptor, where the provider allows
+	StatementDescriptorSuffix string `json:"statement_descriptor_suffix,omitempty"` // appended to the account's descriptor
+	ReceiptEmail              string `json:"receipt_email,omitempty"`               // the provider emails a receipt here, where it can
 }
 
 type UpdateChargeRequest struct {
@@ -271,20 +490,22 @@ type UpdateChargeRequest struct {
 }
 
 type CaptureChargeRequest struct {
-	Amount int64 `json:"amount,omitempty"` // if not provided, captures the full amount
+	Amount   int64 `json:"amount,omitempty"`    // if not provided, captures the full amount
+	KeepOpen bool  `json:"keep_open,omitempty"` // leave the rest of a multi-capture authorization open for further captures
 }
 
 type ListChargesRequest struct {
 	Limit      int    `json:"limit,omitempty"`
-	Offset     int    `json:"offset,omitempty"`
+	Cursor     string `json:"cursor,omitempty"`
 	CustomerID string `json:"customer_id,omitempty"`
 	Status     string `json:"status,omitempty"`
 }
 
 type ChargeList struct {
-	Charges []*Charge `json:"charges"`
-	Total   int       `json:"total"`
-	HasMore bool      `json:"has_more"`
+	Charges    []*Charge `json:"charges"`
+	Total      int       `json:"total"`
+	HasMore    bool      `json:"has_more"`
+	NextCursor string    `json:"next_cursor,omitempty"`
 }
 
 type CreateRefundRequest struct {
@@ -299,14 +520,15 @@ type UpdateRefundRequest struct {
 
 type ListRefundsRequest struct {
 	Limit    int    `json:"limit,omitempty"`
-	Offset   int    `json:"offset,omitempty"`
+	Cursor   string `json:"cursor,omitempty"`
 	ChargeID string `json:"charge_id,omitempty"`
 }
 
 type RefundList struct {
-	Refunds []*Refund `json:"refunds"`
-	Total   int       `json:"total"`
-	HasMore bool      `json:"has_more"`
+	Refunds    []*Refund `json:"refunds"`
+	Total      int       `json:"total"`
+	HasMore    bool      `json:"has_more"`
+	NextCursor string    `json:"next_cursor,omitempty"`
 }
 
 type CreateSubscriptionRequest struct {
@@ -326,7 +548,7 @@ type CancelSubscriptionRequest struct {
 
 type ListSubscriptionsRequest struct {
 	Limit      int    `json:"limit,omitempty"`
-	Offset     int    `json:"offset,omitempty"`
+	Cursor     string `json:"cursor,omitempty"`
 	CustomerID string `json:"customer_id,omitempty"`
 	Status     string `json:"status,omitempty"`
 }
@@ -335,6 +557,124 @@ type SubscriptionList struct {
 	Subscriptions []*Subscription `json:"subscriptions"`
 	Total         int             `json:"total"`
 	HasMore       bool            `json:"has_more"`
+	NextCursor    string          `json:"next_cursor,omitempty"`
+}
+
+type ListDisputesRequest struct {
+	Limit    int    `json:"limit,omitempty"`
+	Cursor   string `json:"cursor,omitempty"`
+	ChargeID string `json:"charge_id,omitempty"`
+	Status   string `json:"status,omitempty"`
+}
+
+type DisputeList struct {
+	Disputes   []*Dispute `json:"disputes"`
+	Total      int        `json:"total"`
+	HasMore    bool       `json:"has_more"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+type DisputeEvidence struct {
+	Type string `json:"type"` // provider evidence type, e.g. REBUTTAL_EXPLANATION
+	Text string `json:"text"`
+}
+
+type SubmitDisputeEvidenceRequest struct {
+	Evidence []DisputeEvidence `json:"evidence"`
+	Submit   bool              `json:"submit"` // true to submit the evidence to the bank
+}
+
+type InvoiceLineItem struct {
+	Amount      int64  `json:"amount"` // in cents
+	Description string `json:"description"`
+}
+
+type CreateInvoiceRequest struct {
+	CustomerID       string                 `json:"customer_id"`
+	Currency         string                 `json:"currency"`
+	Description      string                 `json:"description,omitempty"`
+	CollectionMethod string                 `json:"collection_method,omitempty"` // charge_automatically (default) or send_invoice
+	DaysUntilDue     int                    `json:"days_until_due,omitempty"`    // required for send_invoice
+	Items            []InvoiceLineItem      `json:"items"`
+	Metadata         map[string]interface{} `json:"metadata,omitempty"`
+}
+
+type PayInvoiceRequest struct {
+	PaymentMethodID string `json:"payment_method_id,omitempty"` // defaults to the customer's default payment method
+}
+
+type ListInvoicesRequest struct {
+	Limit      int    `json:"limit,omitempty"`
+	Cursor     string `json:"cursor,omitempty"`
+	CustomerID string `json:"customer_id,omitempty"`
+	Status     string `json:"status,omitempty"`
+}
+
+type InvoiceList struct {
+	Invoices   []*Invoice `json:"invoices"`
+	Total      int        `json:"total"`
+	HasMore    bool       `json:"has_more"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+type CreateConnectedAccountRequest struct {
+	Type     string                 `json:"type,omitempty"` // provider account type, e.g. express (default)
+	Country  string                 `json:"country"`
+	Email    string                 `json:"email,omitempty"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+type CreateOnboardingLinkRequest struct {
+	RefreshURL string `json:"refresh_url"` // where an expired link sends the seller
+	ReturnURL  string `json:"return_url"`
+}
+
+type CreateDestinationChargeRequest struct {
+	CreateChargeRequest
+	ApplicationFeeAmount int64 `json:"application_fee_amount,omitempty"` // kept by the platform, in cents
+}
+
+type CreateTransferRequest struct {
+	AccountID      string                 `json:"account_id"`
+	Amount         int64                  `json:"amount"`
+	Currency       string                 `json:"currency"`
+	SourceChargeID string                 `json:"source_charge_id,omitempty"` // charge the transfer is funded from
+	TransferGroup  string                 `json:"transfer_group,omitempty"`
+	Metadata       map[string]interface{} `json:"metadata,omitempty"`
+}
+
+type ListTransfersRequest struct {
+	Limit     int    `json:"limit,omitempty"`
+	Cursor    string `json:"cursor,omitempty"`
+	AccountID string `json:"account_id,omitempty"`
+}
+
+type TransferList struct {
+	Transfers  []*Transfer `json:"transfers"`
+	Total      int         `json:"total"`
+	HasMore    bool        `json:"has_more"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+type CreatePaymentLinkRequest struct {
+	Amount      int64                  `json:"amount"` // in cents
+	Currency    string                 `json:"currency"`
+	ProductName string                 `json:"product_name"`
+	MaxUses     int64                  `json:"max_uses,omitempty"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+}
+
+type ListPaymentLinksRequest struct {
+	Limit      int    `json:"limit,omitempty"`
+	Cursor     string `json:"cursor,omitempty"`
+	ActiveOnly bool   `json:"active_only,omitempty"`
+}
+
+type PaymentLinkList struct {
+	PaymentLinks []*PaymentLink `json:"payment_links"`
+	Total        int            `json:"total"`
+	HasMore      bool           `json:"has_more"`
+	NextCursor   string         `json:"next_cursor,omitempty"`
 }
 
 // ProviderFactory creates payment gateway instances
@@ -351,7 +691,8 @@ type ProviderFactory interface {
 
 // DefaultProviderFactory implements the ProviderFactory interface
 type DefaultProviderFactory struct {
-	providers map[string]func(map[string]interface{}) (PaymentGateway, error)
+	providers     map[string]func(map[string]interface{}) (PaymentGateway, error)
+	routingPolicy RoutingPolicy
 }
 
 // NewDefaultProviderFactory creates a new default provider factory
@@ -0,0 +1,144 @@
+package invoicing
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"apis/payments/services/stripe"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 500
+)
+
+// Create creates a draft invoice at the provider and stores it
+func (s *Service) Create(ctx context.Context, request *stripe.CreateInvoiceRequest) (*stripe.Invoice, error) {
+	ctx, span := s.tracer.Start(ctx, "Create")
+	defer span.End()
+
+	inv, err := s.invoices.CreateInvoice(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return s.save(ctx, inv)
+}
+
+// Get returns an invoice, fetching it from the provider if it has not been
+// synced yet
+func (s *Service) Get(ctx context.Context, invoiceID string) (*stripe.Invoice, error) {
+	ctx, span := s.tracer.Start(ctx, "Get")
+	defer span.End()
+
+	inv, err := s.store.GetInvoice(ctx, invoiceID)
+	if err == nil {
+		return inv, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	inv, err = s.invoices.GetInvoice(ctx, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	return s.save(ctx, inv)
+}
+
+// List returns synced invoices, newest first
+func (s *Service) List(ctx context.Context, filter InvoiceFilter) ([]*stripe.Invoice, error) {
+	ctx, span := s.tracer.Start(ctx, "List")
+	defer span.End()
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultListLimit
+	}
+	if filter.Limit > maxListLimit {
+		filter.Limit = maxListLimit
+	}
+
+	return s.store.ListInvoices(ctx, filter)
+}
+
+// Finalize finalizes a draft invoice. Invoices collected automatically are
+// then charged by the provider; send_invoice invoices are emailed.
+func (s *Service) Finalize(ctx context.Context, invoiceID string) (*stripe.Invoice, error) {
+	ctx, span := s.tracer.Start(ctx, "Finalize")
+	defer span.End()
+
+	inv, err := s.invoices.FinalizeInvoice(ctx, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	return s.save(ctx, inv)
+}
+
+// Pay pays an open invoice now, with the given payment method or the
+// customer's default one
+func (s *Service) Pay(ctx context.Context, invoiceID, paymentMethodID string) (*stripe.Invoice, error) {
+	ctx, span := s.tracer.Start(ctx, "Pay")
+	defer span.End()
+
+	inv, err := s.invoices.PayInvoice(ctx, invoiceID, paymentMethodID)
+	if err != nil {
+		return nil, err
+	}
+	return s.save(ctx, inv)
+}
+
+// Void voids an open invoice
+func (s *Service) Void(ctx context.Context, invoiceID string) (*stripe.Invoice, error) {
+	ctx, span := s.tracer.Start(ctx, "Void")
+	defer span.End()
+
+	inv, err := s.invoices.VoidInvoice(ctx, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	return s.save(ctx, inv)
+}
+
+// PDFURL returns where an invoice's PDF can be downloaded. A stored draft is
+// refreshed from the provider in case it was finalized since it was synced.
+func (s *Service) PDFURL(ctx context.Context, invoiceID string) (string, error) {
+	ctx, span := s.tracer.Start(ctx, "PDFURL")
+	defer span.End()
+
+	inv, err := s.Get(ctx, invoiceID)
+	if err != nil {
+		return "", err
+	}
+	if inv.InvoicePDF == "" && inv.Status == "draft" {
+		if inv, err = s.invoices.GetInvoice(ctx, invoiceID); err != nil {
+			return "", err
+		}
+		if inv, err = s.save(ctx, inv); err != nil {
+			return "", err
+		}
+	}
+	if inv.InvoicePDF == "" {
+		return "", ErrPDFUnavailable
+	}
+
+	return inv.InvoicePDF, nil
+}
+
+// Sync stores the provider's view of an invoice as of syncedAt
+func (s *Service) Sync(ctx context.Context, inv *stripe.Invoice, syncedAt time.Time) error {
+	ctx, span := s.tracer.Start(ctx, "Sync")
+	defer span.End()
+
+	return s.store.UpsertInvoice(ctx, inv, syncedAt)
+}
+
+// save stores an invoice returned by the provider. Provider event times have
+// second precision, so the sync time is truncated to let events from the
+// same second still apply.
+func (s *Service) save(ctx context.Context, inv *stripe.Invoice) (*stripe.Invoice, error) {
+	if err := s.store.UpsertInvoice(ctx, inv, time.Now().Truncate(time.Second)); err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
@@ -0,0 +1,142 @@
+package invoicing
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"apis/payments/services/stripe"
+)
+
+// Receivable invoice statuses, matching Stripe invoice statuses
+const (
+	StatusOpen          = "open"
+	StatusPaid          = "paid"
+	StatusVoid          = "void"
+	StatusUncollectible = "uncollectible"
+)
+
+// Event types emitted for invoices with payment terms
+const (
+	EventInvoiceReminder   = "payments.invoice.reminder"
+	EventInvoiceOverdue    = "payments.invoice.overdue"
+	EventInvoiceMarkedPaid = "payments.invoice.marked_paid"
+)
+
+// ErrPDFUnavailable is returned when requesting the PDF of an invoice that has not been finalized
+var ErrPDFUnavailable = errors.New("invoice PDF is available once the invoice is finalized")
+
+// LedgerReferenceType identifies invoice ledger entries
+const LedgerReferenceType = "invoice"
+
+// Invoice is an invoice sent with payment terms, tracked until it is settled
+type Invoice struct {
+	InvoiceID       string     `json:"invoice_id"`
+	CustomerID      string     `json:"customer_id"`
+	SubscriptionID  string     `json:"subscription_id,omitempty"`
+	Number          string     `json:"number,omitempty"`
+	AmountDue       int64      `json:"amount_due"`
+	AmountRemaining int64      `json:"amount_remaining"`
+	Currency        string     `json:"currency"`
+	DueDate         time.Time  `json:"due_date"`
+	Status          string     `json:"status"`
+	OverdueAt       *time.Time `json:"overdue_at,omitempty"`
+	PaidAt          *time.Time `json:"paid_at,omitempty"`
+	PaidReference   string     `json:"paid_reference,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// DaysOverdue returns how many whole days past due the invoice is at now
+func (i *Invoice) DaysOverdue(now time.Time) int {
+	if !now.After(i.DueDate) {
+		return 0
+	}
+	return int(now.Sub(i.DueDate) / (24 * time.Hour))
+}
+
+// Reminder is the payload of an invoice reminder event
+type Reminder struct {
+	Invoice     *Invoice `json:"invoice"`
+	DaysFromDue int      `json:"days_from_due"` // Negative before the due date
+}
+
+// MarkPaidRequest records an offline payment of an invoice
+type MarkPaidRequest struct {
+	Reference string `json:"reference" validate:"required"` // Bank transfer reference
+	PaidAt    string `json:"paid_at,omitempty"`             // RFC 3339, defaults to now
+}
+
+// Config controls invoice reminders
+type Config struct {
+	// ReminderOffsets are days relative to the due date at which reminders are
+	// sent; negative offsets remind before the invoice is due
+	ReminderOffsets []int
+	Interval        time.Duration
+}
+
+// LoadConfig loads the invoice reminder configuration from environment variables
+func LoadConfig() *Config {
+	config := &Config{
+		ReminderOffsets: []int{-3, 1, 7, 14, 30},
+		Interval:        time.Hour,
+	}
+
+	if value := os.Getenv("INVOICE_REMINDER_DAYS"); value != "" {
+		var offsets []int
+		for _, part := range strings.Split(value, ",") {
+			offset, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil {
+				continue
+			}
+			offsets = append(offsets, offset)
+		}
+		config.ReminderOffsets = offsets
+	}
+	if minutes, err := strconv.Atoi(os.Getenv("INVOICE_REMINDER_INTERVAL_MINUTES")); err == nil && minutes > 0 {
+		config.Interval = time.Duration(minutes) * time.Minute
+	}
+
+	sort.Ints(config.ReminderOffsets)
+	return config
+}
+
+// InvoiceFilter narrows an invoice listing
+type InvoiceFilter struct {
+	CustomerID string
+	Status     string
+	Limit      int
+}
+
+// Store persists invoices, the receivable invoices among them and the
+// reminders sent for those. Invoice upserts older than the stored row are
+// ignored so out-of-order webhooks cannot roll an invoice back.
+type Store interface {
+	UpsertInvoice(ctx context.Context, invoice *stripe.Invoice, syncedAt time.Time) error
+	GetInvoice(ctx context.Context, invoiceID string) (*stripe.Invoice, error)
+	ListInvoices(ctx context.Context, filter InvoiceFilter) ([]*stripe.Invoice, error)
+	DeleteInvoice(ctx context.Context, invoiceID string) error
+
+	GetReceivableInvoice(ctx context.Context, invoiceID string) (*Invoice, error)
+	UpsertReceivableInvoice(ctx context.Context, invoice *Invoice) (*Invoice, error)
+	ListOpenReceivableInvoices(ctx context.Context) ([]*Invoice, error)
+	ListOverdueReceivableInvoices(ctx context.Context, customerID string, now time.Time) ([]*Invoice, error)
+	MarkReceivableInvoiceOverdue(ctx context.Context, invoiceID string, at time.Time) error
+	MarkReceivableInvoicePaid(ctx context.Context, invoiceID, reference string, at time.Time) (*Invoice, error)
+	ListInvoiceReminderOffsets(ctx context.Context, invoiceID string) ([]int, error)
+	RecordInvoiceReminder(ctx context.Context, invoiceID string, offsetDays int) error
+}
+
+// InvoiceProvider creates and settles invoices at the payment provider
+type InvoiceProvider interface {
+	CreateInvoice(ctx context.Context, request *stripe.CreateInvoiceRequest) (*stripe.Invoice, error)
+	GetInvoice(ctx context.Context, invoiceID string) (*stripe.Invoice, error)
+	FinalizeInvoice(ctx context.Context, invoiceID string) (*stripe.Invoice, error)
+	PayInvoice(ctx context.Context, invoiceID, paymentMethodID string) (*stripe.Invoice, error)
+	VoidInvoice(ctx context.Context, invoiceID string) (*stripe.Invoice, error)
+	MarkInvoicePaidOutOfBand(ctx context.Context, invoiceID string) (*stripe.Invoice, error)
+}
@@ -0,0 +1,290 @@
+package invoicing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"apis/payments/services/events"
+	"apis/payments/services/ledger"
+	"apis/payments/services/stripe"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrInvoiceNotOpen is returned when settling an invoice that is not open
+var ErrInvoiceNotOpen = errors.New("invoice is not open")
+
+// Service creates and settles invoices and keeps a local copy of each,
+// tracks invoices sent with payment terms, sends reminders and records
+// offline payments
+type Service struct {
+	store     Store
+	invoices  InvoiceProvider
+	ledger    *ledger.Service
+	emitter   *events.Emitter
+	config    *Config
+	validator *validator.Validate
+	tracer    trace.Tracer
+}
+
+// NewService creates a new invoicing service
+func NewService(store Store, invoices InvoiceProvider, ledgerService *ledger.Service, emitter *events.Emitter, config *Config) *Service {
+	if config == nil {
+		config = LoadConfig()
+	}
+
+	return &Service{
+		store:     store,
+		invoices:  invoices,
+		ledger:    ledgerService,
+		emitter:   emitter,
+		config:    config,
+		validator: validator.New(),
+		tracer:    otel.Tracer("payments.invoicing"),
+	}
+}
+
+// Track records the latest state of a send_invoice invoice and posts its
+// ledger entries. Invoices charged automatically or without a due date are
+// ignored.
+func (s *Service) Track(ctx context.Context, inv *stripe.Invoice) error {
+	ctx, span := s.tracer.Start(ctx, "Track")
+	defer span.End()
+
+	if inv.CollectionMethod != "send_invoice" || inv.DueDate == nil {
+		return nil
+	}
+	// Drafts have no number or final amount yet
+	if inv.Status == "draft" {
+		return nil
+	}
+
+	tracked, err := s.store.UpsertReceivableInvoice(ctx, &Invoice{
+		InvoiceID:       inv.ID,
+		CustomerID:      inv.CustomerID,
+		SubscriptionID:  inv.SubscriptionID,
+		Number:          inv.Number,
+		AmountDue:       inv.AmountDue,
+		AmountRemaining: inv.AmountRemaining,
+		Currency:        inv.Currency,
+		DueDate:         *inv.DueDate,
+		Status:          inv.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to track invoice: %w", err)
+	}
+
+	if tracked.AmountDue <= 0 {
+		return nil
+	}
+
+	// Amounts are receivable once the invoice is issued
+	if err := s.post(ctx, tracked, ledger.AccountReceivable, ledger.AccountRevenue, "Invoice issued"); err != nil {
+		return err
+	}
+
+	switch tracked.Status {
+	case StatusPaid:
+		debit := ledger.AccountProviderBalance
+		if inv.PaidOutOfBand {
+			debit = ledger.AccountBank
+		}
+		return s.post(ctx, tracked, debit, ledger.AccountReceivable, "Invoice paid")
+	case StatusVoid:
+		return s.post(ctx, tracked, ledger.AccountRevenue, ledger.AccountReceivable, "Invoice voided")
+	case StatusUncollectible:
+		return s.post(ctx, tracked, ledger.AccountBadDebt, ledger.AccountReceivable, "Invoice written off")
+	}
+
+	return nil
+}
+
+// post records a ledger entry for an invoice. Entries are keyed by invoice
+// and accounts, so replayed events don't post twice.
+func (s *Service) post(ctx context.Context, inv *Invoice, debit, credit, description string) error {
+	label := inv.InvoiceID
+	if inv.Number != "" {
+		label = inv.Number
+	}
+
+	if err := s.ledger.Post(ctx, &ledger.Entry{
+		ID:            fmt.Sprintf("le_%s", uuid.New().String()),
+		DebitAccount:  debit,
+		CreditAccount: credit,
+		Amount:        inv.AmountDue,
+		Currency:      inv.Currency,
+		ReferenceType: LedgerReferenceType,
+		ReferenceID:   inv.InvoiceID,
+		Description:   fmt.Sprintf("%s %s", description, label),
+	}); err != nil {
+		return fmt.Errorf("failed to post invoice ledger entry: %w", err)
+	}
+
+	return nil
+}
+
+// MarkPaid records an offline bank transfer against an open invoice: the
+// invoice is marked paid out of band at the provider and the payment is
+// posted to the bank account in the ledger
+func (s *Service) MarkPaid(ctx context.Context, invoiceID string, request *MarkPaidRequest) (*Invoice, error) {
+	ctx, span := s.tracer.Start(ctx, "MarkPaid")
+	defer span.End()
+
+	if err := s.validator.Struct(request); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	paidAt := time.Now().UTC()
+	if request.PaidAt != "" {
+		parsed, err := time.Parse(time.RFC3339, request.PaidAt)
+		if err != nil {
+			return nil, fmt.Errorf("validation failed: paid_at must be an RFC 3339 timestamp")
+		}
+		paidAt = parsed
+	}
+
+	tracked, err := s.store.GetReceivableInvoice(ctx, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	if tracked.Status != StatusOpen {
+		return nil, fmt.Errorf("%w: %s is %s", ErrInvoiceNotOpen, invoiceID, tracked.Status)
+	}
+
+	if _, err := s.invoices.MarkInvoicePaidOutOfBand(ctx, invoiceID); err != nil {
+		return nil, err
+	}
+
+	paid, err := s.store.MarkReceivableInvoicePaid(ctx, invoiceID, request.Reference, paidAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark invoice paid: %w", err)
+	}
+
+	if err := s.post(ctx, paid, ledger.AccountBank, ledger.AccountReceivable, fmt.Sprintf("Bank transfer %s for invoice", request.Reference)); err != nil {
+		return nil, err
+	}
+
+	s.emit(ctx, EventInvoiceMarkedPaid, paid.InvoiceID, paid)
+	return paid, nil
+}
+
+// ListOverdue returns open invoices past their due date, optionally for one customer
+func (s *Service) ListOverdue(ctx context.Context, customerID string) ([]*Invoice, error) {
+	ctx, span := s.tracer.Start(ctx, "ListOverdue")
+	defer span.End()
+
+	return s.store.ListOverdueReceivableInvoices(ctx, customerID, time.Now())
+}
+
+// SendReminders flags invoices that have become overdue and emits a reminder
+// for each invoice that has reached a reminder offset. When several offsets
+// were reached since the last run, only the latest is sent.
+func (s *Service) SendReminders(ctx context.Context, now time.Time) (int, error) {
+	ctx, span := s.tracer.Start(ctx, "SendReminders")
+	defer span.End()
+
+	invoices, err := s.store.ListOpenReceivableInvoices(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list open invoices: %w", err)
+	}
+
+	sent := 0
+	for _, inv := range invoices {
+		if now.After(inv.DueDate) && inv.OverdueAt == nil {
+			if err := s.store.MarkReceivableInvoiceOverdue(ctx, inv.InvoiceID, now); err != nil {
+				return sent, fmt.Errorf("failed to mark invoice overdue: %w", err)
+			}
+			inv.OverdueAt = &now
+			s.emit(ctx, EventInvoiceOverdue, inv.InvoiceID, inv)
+		}
+
+		reminded, err := s.remind(ctx, inv, now)
+		if err != nil {
+			return sent, err
+		}
+		if reminded {
+			sent++
+		}
+	}
+
+	return sent, nil
+}
+
+// remind sends the latest reminder an invoice is due, if any
+func (s *Service) remind(ctx context.Context, inv *Invoice, now time.Time) (bool, error) {
+	sentOffsets, err := s.store.ListInvoiceReminderOffsets(ctx, inv.InvoiceID)
+	if err != nil {
+		return false, fmt.Errorf("failed to list invoice reminders: %w", err)
+	}
+	sent := make(map[int]bool, len(sentOffsets))
+	for _, offset := range sentOffsets {
+		sent[offset] = true
+	}
+
+	var reached []int
+	for _, offset := range s.config.ReminderOffsets {
+		if !now.Before(inv.DueDate.AddDate(0, 0, offset)) && !sent[offset] {
+			reached = append(reached, offset)
+		}
+	}
+	if len(reached) == 0 {
+		return false, nil
+	}
+
+	for _, offset := range reached {
+		if err := s.store.RecordInvoiceReminder(ctx, inv.InvoiceID, offset); err != nil {
+			return false, fmt.Errorf("failed to record invoice reminder: %w", err)
+		}
+	}
+
+	s.emit(ctx, EventInvoiceReminder, inv.InvoiceID, &Reminder{
+		Invoice:     inv,
+		DaysFromDue: reached[len(reached)-1],
+	})
+	return true, nil
+}
+
+// emit publishes an invoice event. Publishing failures are logged.
+func (s *Service) emit(ctx context.Context, eventType, subject string, data any) {
+	if s.emitter == nil {
+		return
+	}
+	if err := s.emitter.Emit(ctx, "invoices", eventType, subject, data); err != nil {
+		log.Printf("Failed to emit %s for %s: %v", eventType, subject, err)
+	}
+}
+
+// Start sends reminders every configured interval until the returned stop
+// function is called
+func (s *Service) Start() (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(s.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := s.SendReminders(context.Background(), time.Now()); err != nil {
+					log.Printf("Invoice reminder run failed: %v", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
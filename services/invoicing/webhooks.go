@@ -0,0 +1,52 @@
+package invoicing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"apis/payments/services/stripe"
+
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// invoiceEvents are the invoice lifecycle events that change what a customer owes
+var invoiceEvents = []stripego.EventType{
+	stripego.EventTypeInvoiceCreated,
+	stripego.EventTypeInvoiceFinalized,
+	stripego.EventTypeInvoiceUpdated,
+	stripego.EventTypeInvoicePaid,
+	stripego.EventTypeInvoicePaymentFailed,
+	stripego.EventTypeInvoiceVoided,
+	stripego.EventTypeInvoiceMarkedUncollectible,
+}
+
+// RegisterWebhookHandlers keeps stored and receivable invoices in step with Stripe
+func (s *Service) RegisterWebhookHandlers(webhooks *stripe.WebhookService) {
+	for _, eventType := range invoiceEvents {
+		webhooks.On(eventType, func(ctx context.Context, event stripego.Event) error {
+			var stripeInvoice stripego.Invoice
+			if err := json.Unmarshal(event.Data.Raw, &stripeInvoice); err != nil {
+				return fmt.Errorf("failed to parse invoice: %w", err)
+			}
+
+			inv := stripe.ConvertInvoice(&stripeInvoice)
+			if err := s.Sync(ctx, inv, time.Unix(event.Created, 0)); err != nil {
+				return fmt.Errorf("failed to sync invoice: %w", err)
+			}
+
+			return s.Track(ctx, inv)
+		})
+	}
+
+	// Only drafts can be deleted, and drafts are never tracked as receivable
+	webhooks.On(stripego.EventTypeInvoiceDeleted, func(ctx context.Context, event stripego.Event) error {
+		var stripeInvoice stripego.Invoice
+		if err := json.Unmarshal(event.Data.Raw, &stripeInvoice); err != nil {
+			return fmt.Errorf("failed to parse invoice: %w", err)
+		}
+
+		return s.store.DeleteInvoice(ctx, stripeInvoice.ID)
+	})
+}
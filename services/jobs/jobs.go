@@ -0,0 +1,109 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"apis/payments/services/config"
+)
+
+// Run statuses
+const (
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusAbandoned = "abandoned" // The instance running it stopped before it finished
+)
+
+// What started a run
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+)
+
+// ErrJobNotFound is returned for jobs that aren't registered
+var ErrJobNotFound = errors.New("job not found")
+
+// ErrJobRunning is returned when starting a job that is already running on
+// any instance
+var ErrJobRunning = errors.New("job is already running")
+
+// ErrInvalidSchedule is returned for schedules that can't be parsed
+var ErrInvalidSchedule = errors.New("invalid job schedule")
+
+// Job is a task run on a schedule by one instance at a time
+type Job struct {
+	Name     string
+	Schedule string // Cron expression, see ParseSchedule
+	Run      func(ctx context.Context) error
+	// MaxAttempts overrides how often a failing run is attempted
+	MaxAttempts int
+}
+
+// Run is one execution of a job
+type Run struct {
+	ID          string     `json:"id"`
+	Job         string     `json:"job"`
+	Status      string     `json:"status"`
+	TriggeredBy string     `json:"triggered_by"`
+	Attempts    int        `json:"attempts"`
+	Error       string     `json:"error,omitempty"`
+	Instance    string     `json:"instance"`
+	StartedAt   time.Time  `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
+
+// Status describes a registered job
+type Status struct {
+	Name      string     `json:"name"`
+	Schedule  string     `json:"schedule"`
+	NextRunAt *time.Time `json:"next_run_at,omitempty"`
+	Running   bool       `json:"running"` // Running on this instance
+	LastRun   *Run       `json:"last_run,omitempty"`
+}
+
+// Config controls job scheduling
+type Config struct {
+	MaxAttempts  int           // How often a failing run is attempted
+	RetryBackoff time.Duration // Wait before the first retry, doubling after each
+	// Schedules overrides the schedule of jobs by name
+	Schedules map[string]string
+}
+
+// LoadConfig loads the job configuration from environment variables.
+// JOB_SCHEDULES overrides schedules as name=expression pairs separated by
+// semicolons, e.g. "reconciliation=0 4 * * *;dunning=@every 30m".
+func LoadConfig() *Config {
+	config := &Config{
+		MaxAttempts:  config.Int("JOBS_MAX_ATTEMPTS", 3),
+		RetryBackoff: time.Duration(config.Int("JOBS_RETRY_BACKOFF_SECONDS", 30)) * time.Second,
+		Schedules:    map[string]string{},
+	}
+
+	for _, pair := range strings.Split(os.Getenv("JOB_SCHEDULES"), ";") {
+		name, schedule, ok := strings.Cut(pair, "=")
+		if ok && strings.TrimSpace(name) != "" {
+			config.Schedules[strings.TrimSpace(name)] = strings.TrimSpace(schedule)
+		}
+	}
+
+	return config
+}
+
+// Store persists job runs
+type Store interface {
+	CreateJobRun(ctx context.Context, run *Run) (*Run, error)
+	FinishJobRun(ctx context.Context, run *Run) (*Run, error)
+	// AbandonJobRuns marks a job's runs still running as abandoned
+	AbandonJobRuns(ctx context.Context, job string, at time.Time) error
+	ListJobRuns(ctx context.Context, job string, limit int) ([]*Run, error)
+}
+
+// Locker holds a lock per job across instances. Locks are released by the
+// returned unlock func, or when the holder goes away.
+type Locker interface {
+	TryJobLock(ctx context.Context, job string) (unlock func(), locked bool, err error)
+}
@@ -0,0 +1,333 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// entry is a registered job and when it next runs
+type entry struct {
+	job      *Job
+	spec     string
+	schedule Schedule
+	next     time.Time
+	running  bool
+}
+
+// Scheduler runs jobs on their schedules. Every instance runs a scheduler,
+// and a lock per job makes sure only one of them runs a job at a time. Each
+// run is recorded with its attempts and outcome.
+type Scheduler struct {
+	store    Store
+	locker   Locker
+	config   *Config
+	instance string
+	tracer   trace.Tracer
+
+	mu      sync.Mutex
+	entries map[string]*entry
+	wake    chan struct{}
+	done    chan struct{}
+	runs    sync.WaitGroup
+}
+
+// NewScheduler creates a new job scheduler
+func NewScheduler(store Store, locker Locker, config *Config) *Scheduler {
+	instance, err := os.Hostname()
+	if err != nil {
+		instance = "unknown"
+	}
+
+	return &Scheduler{
+		store:    store,
+		locker:   locker,
+		config:   config,
+		instance: instance,
+		tracer:   otel.Tracer("payments.jobs"),
+		entries:  map[string]*entry{},
+		wake:     make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+}
+
+// Register adds a job to the schedule, applying any configured schedule
+// override. A nil job is ignored, so disabled jobs can be registered as is.
+func (s *Scheduler) Register(job *Job) error {
+	if job == nil {
+		return nil
+	}
+
+	spec := job.Schedule
+	if override, ok := s.config.Schedules[job.Name]; ok {
+		spec = override
+	}
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", job.Name, err)
+	}
+
+	s.mu.Lock()
+	s.entries[job.Name] = &entry{job: job, spec: spec, schedule: schedule, next: schedule.Next(time.Now())}
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Start runs jobs as they come due until the returned stop function is
+// called. Stopping waits for runs in progress to finish, but stops retrying
+// failed ones.
+func (s *Scheduler) Start() (stop func()) {
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		timer := time.NewTimer(s.untilNext(time.Now()))
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-timer.C:
+				s.runDue(time.Now())
+			case <-s.wake:
+				timer.Stop()
+			case <-s.done:
+				return
+			}
+			timer.Reset(s.untilNext(time.Now()))
+		}
+	}()
+
+	return func() {
+		close(s.done)
+		<-stopped
+		s.runs.Wait()
+	}
+}
+
+// untilNext returns how long until the next job is due
+func (s *Scheduler) untilNext(now time.Time) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	until := time.Hour
+	for _, e := range s.entries {
+		if !e.next.IsZero() && e.next.Sub(now) < until {
+			until = e.next.Sub(now)
+		}
+	}
+	return max(until, 0)
+}
+
+// runDue starts the jobs that are due, skipping those still running here
+func (s *Scheduler) runDue(now time.Time) {
+	s.mu.Lock()
+	due := []*entry{}
+	for _, e := range s.entries {
+		if e.next.IsZero() || e.next.After(now) {
+			continue
+		}
+		e.next = e.schedule.Next(now)
+		if !e.running {
+			due = append(due, e)
+		}
+	}
+	s.mu.Unlock()
+
+	// Jobs locked by another instance are running there
+	for _, e := range due {
+		if _, err := s.begin(context.Background(), e, TriggerSchedule); err != nil && err != ErrJobRunning {
+			log.Printf("Failed to start job %s: %v", e.job.Name, err)
+		}
+	}
+}
+
+// RunNow starts a job outside its schedule and returns the run recorded for
+// it. The job runs in the background.
+func (s *Scheduler) RunNow(ctx context.Context, name string) (*Run, error) {
+	s.mu.Lock()
+	e, ok := s.entries[name]
+	s.mu.Unlock()
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+
+	return s.begin(ctx, e, TriggerManual)
+}
+
+// begin takes the job's lock and records a run, then attempts the job in
+// the background. Runs left running by instances that went away are
+// abandoned first, since holding the lock means none is in progress.
+func (s *Scheduler) begin(ctx context.Context, e *entry, trigger string) (*Run, error) {
+	s.mu.Lock()
+	if e.running {
+		s.mu.Unlock()
+		return nil, ErrJobRunning
+	}
+	e.running = true
+	s.mu.Unlock()
+
+	run, unlock, err := s.record(ctx, e.job.Name, trigger)
+	if err != nil {
+		s.mu.Lock()
+		e.running = false
+		s.mu.Unlock()
+		return nil, err
+	}
+
+	s.runs.Add(1)
+	go func() {
+		defer s.runs.Done()
+		defer func() {
+			s.mu.Lock()
+			e.running = false
+			s.mu.Unlock()
+		}()
+		defer unlock()
+
+		s.execute(e.job, run)
+	}()
+
+	return run, nil
+}
+
+// record takes the job's lock and stores a new run of it
+func (s *Scheduler) record(ctx context.Context, job, trigger string) (*Run, func(), error) {
+	unlock, locked, err := s.locker.TryJobLock(ctx, job)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to lock job: %w", err)
+	}
+	if !locked {
+		return nil, nil, ErrJobRunning
+	}
+
+	now := time.Now()
+	if err := s.store.AbandonJobRuns(ctx, job, now); err != nil {
+		unlock()
+		return nil, nil, err
+	}
+
+	run, err := s.store.CreateJobRun(ctx, &Run{
+		ID:          "jrn_" + uuid.New().String(),
+		Job:         job,
+		Status:      StatusRunning,
+		TriggeredBy: trigger,
+		Instance:    s.instance,
+		StartedAt:   now,
+	})
+	if err != nil {
+		unlock()
+		return nil, nil, err
+	}
+
+	return run, unlock, nil
+}
+
+// execute attempts a job until it succeeds or runs out of attempts, waiting
+// longer between each, then records the outcome
+func (s *Scheduler) execute(job *Job, run *Run) {
+	ctx, span := s.tracer.Start(context.Background(), "Run")
+	defer span.End()
+
+	maxAttempts := s.config.MaxAttempts
+	if job.MaxAttempts > 0 {
+		maxAttempts = job.MaxAttempts
+	}
+	backoff := s.config.RetryBackoff
+
+	var err error
+	for {
+		run.Attempts++
+		if err = job.Run(ctx); err == nil {
+			break
+		}
+		log.Printf("Job %s failed on attempt %d: %v", job.Name, run.Attempts, err)
+		if run.Attempts >= maxAttempts || !s.wait(backoff) {
+			break
+		}
+		backoff *= 2
+	}
+
+	finished := time.Now()
+	run.Status = StatusSucceeded
+	run.FinishedAt = &finished
+	if err != nil {
+		run.Status = StatusFailed
+		run.Error = err.Error()
+	}
+
+	if _, err := s.store.FinishJobRun(ctx, run); err != nil {
+		log.Printf("Failed to record run %s of job %s: %v", run.ID, job.Name, err)
+	}
+}
+
+// wait waits before retrying, returning false if the scheduler stops first
+func (s *Scheduler) wait(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+// Jobs lists the registered jobs by name with their latest run
+func (s *Scheduler) Jobs(ctx context.Context) ([]*Status, error) {
+	s.mu.Lock()
+	statuses := make([]*Status, 0, len(s.entries))
+	for name, e := range s.entries {
+		status := &Status{Name: name, Schedule: e.spec, Running: e.running}
+		if !e.next.IsZero() {
+			next := e.next
+			status.NextRunAt = &next
+		}
+		statuses = append(statuses, status)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+
+	for _, status := range statuses {
+		runs, err := s.store.ListJobRuns(ctx, status.Name, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(runs) > 0 {
+			status.LastRun = runs[0]
+		}
+	}
+
+	return statuses, nil
+}
+
+// Runs lists a job's runs across instances, newest first
+func (s *Scheduler) Runs(ctx context.Context, name string, limit int) ([]*Run, error) {
+	s.mu.Lock()
+	_, ok := s.entries[name]
+	s.mu.Unlock()
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	return s.store.ListJobRuns(ctx, name, limit)
+}
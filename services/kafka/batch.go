@@ -0,0 +1,217 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"apis/payments/services/batching"
+
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// BatchHandler processes consumed messages a batch at a time. A batch's
+// offsets are marked consumed only once HandleBatch returns nil, so a
+// handler that fails is given the same batch again.
+type BatchHandler interface {
+	HandleBatch(ctx context.Context, messages []*Message) error
+}
+
+// BatchHandlerFunc adapts a function to a BatchHandler
+type BatchHandlerFunc func(ctx context.Context, messages []*Message) error
+
+// HandleBatch calls f
+func (f BatchHandlerFunc) HandleBatch(ctx context.Context, messages []*Message) error {
+	return f(ctx, messages)
+}
+
+// BatchConsumer reads topics as a member of a consumer group, handing each
+// partition's messages to its handler in batches of up to the controller's
+// size, or whatever arrived within interval. The size adapts to how long
+// batches take to handle and whether they fail; a partition's batches are
+// handled in order, so their concurrency does not. Failed batches are
+// retried with backoff until they succeed or the session ends; there is no
+// dead-letter topic, so handlers skip messages they can never handle.
+type BatchConsumer struct {
+	group    sarama.ConsumerGroup
+	handler  BatchHandler
+	config   *Config
+	batches  *batching.Controller
+	interval time.Duration
+	tracer   trace.Tracer
+	cancel   context.CancelFunc
+	stopped  chan struct{}
+}
+
+// NewBatchConsumer joins the configured consumer group
+func NewBatchConsumer(config *Config, handler BatchHandler, batches *batching.Controller, interval time.Duration) (*BatchConsumer, error) {
+	saramaConfig := sarama.NewConfig()
+	saramaConfig.ClientID = config.GroupID
+	saramaConfig.Consumer.Offsets.Initial = sarama.OffsetOldest
+	saramaConfig.Consumer.Offsets.AutoCommit.Enable = true
+
+	group, err := sarama.NewConsumerGroup(config.Brokers, config.GroupID, saramaConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create consumer group: %w", err)
+	}
+
+	return NewBatchConsumerWithGroup(config, group, handler, batches, interval), nil
+}
+
+// NewBatchConsumerWithGroup creates a batch consumer from an existing group
+func NewBatchConsumerWithGroup(config *Config, group sarama.ConsumerGroup, handler BatchHandler, batches *batching.Controller, interval time.Duration) *BatchConsumer {
+	return &BatchConsumer{
+		group:    group,
+		handler:  handler,
+		config:   config,
+		batches:  batches,
+		interval: max(interval, time.Millisecond),
+		tracer:   otel.Tracer("payments.kafka"),
+	}
+}
+
+// Start consumes in the background until Close is called
+func (c *BatchConsumer) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	c.stopped = make(chan struct{})
+
+	go func() {
+		defer close(c.stopped)
+		for {
+			// Consume returns at each rebalance and must be called again
+			err := c.group.Consume(ctx, c.config.Topics, c)
+			if errors.Is(err, sarama.ErrClosedConsumerGroup) || ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				log.Printf("Kafka batch consumer session failed: %v", err)
+				select {
+				case <-time.After(c.config.backoff(1)):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+}
+
+// Close stops consuming, letting the batch being handled finish, then
+// commits marked offsets and leaves the group. Batches not yet handled are
+// redelivered to the next owner of their partition.
+func (c *BatchConsumer) Close(ctx context.Context) error {
+	var errs []error
+	if c.cancel != nil {
+		c.cancel()
+		select {
+		case <-c.stopped:
+		case <-ctx.Done():
+			errs = append(errs, fmt.Errorf("batch consumer did not stop: %w", ctx.Err()))
+		}
+	}
+
+	if err := c.group.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to leave consumer group: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Setup is called when a session begins
+func (c *BatchConsumer) Setup(session sarama.ConsumerGroupSession) error {
+	log.Printf("Kafka batch consumer joined group %s with claims %v", c.config.GroupID, session.Claims())
+	return nil
+}
+
+// Cleanup is called when a session ends, after every claim has returned
+func (c *BatchConsumer) Cleanup(session sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// ConsumeClaim batches a partition's messages until the session ends,
+// marking each batch once it is handled
+func (c *BatchConsumer) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	var batch []*sarama.ConsumerMessage
+	flush := func() bool {
+		if len(batch) == 0 {
+			return true
+		}
+		if !c.process(session.Context(), batch) {
+			// Unmarked, so the batch is redelivered to the next owner
+			return false
+		}
+		session.MarkMessage(batch[len(batch)-1], "")
+		batch = nil
+		return true
+	}
+
+	for {
+		select {
+		case message, ok := <-claim.Messages():
+			if !ok {
+				flush()
+				return nil
+			}
+			batch = append(batch, message)
+			if len(batch) >= c.batches.Size() && !flush() {
+				return nil
+			}
+		case <-ticker.C:
+			if !flush() {
+				return nil
+			}
+		case <-session.Context().Done():
+			return nil
+		}
+	}
+}
+
+// process handles a batch, retrying with backoff until it succeeds, and
+// adapts the batch size to each attempt. It reports false when the session
+// ended before the batch was handled.
+func (c *BatchConsumer) process(ctx context.Context, batch []*sarama.ConsumerMessage) bool {
+	messages := make([]*Message, len(batch))
+	for i, message := range batch {
+		messages[i] = convertMessage(message)
+	}
+	first := messages[0]
+
+	ctx, span := c.tracer.Start(ctx, "BatchConsumer.HandleBatch", trace.WithAttributes(
+		attribute.String("messaging.destination", first.Topic),
+		attribute.Int64("messaging.kafka.partition", int64(first.Partition)),
+		attribute.Int64("messaging.kafka.offset", first.Offset),
+		attribute.Int("messaging.batch.message_count", len(messages)),
+	))
+	defer span.End()
+
+	for attempt := 1; ; attempt++ {
+		// A batch that has started writing is allowed to finish even when
+		// shutdown begins
+		start := time.Now()
+		err := c.handler.HandleBatch(context.WithoutCancel(ctx), messages)
+		result := batching.Result{Items: len(messages), Latency: time.Since(start)}
+		if err != nil {
+			result.Failures = len(messages)
+		}
+		c.batches.Observe(result)
+		if err == nil {
+			return true
+		}
+		log.Printf("Kafka batch %s/%d/%d-%d failed attempt %d: %v", first.Topic, first.Partition, first.Offset, messages[len(messages)-1].Offset, attempt, err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		if !sleep(ctx, c.config.backoff(attempt)) {
+			return false
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Consumer reads topics as a member of a consumer group. Partitions are
+// processed in order, one message at a time; a message's offset is committed
+// only after it is handled or dead-lettered, so delivery is at least once.
+type Consumer struct {
+	group    sarama.ConsumerGroup
+	producer sarama.SyncProducer
+	handler  Handler
+	recorder Recorder
+	config   *Config
+	tracer   trace.Tracer
+	cancel   context.CancelFunc
+	stopped  chan struct{}
+}
+
+// NewConsumer joins the configured consumer group and connects the
+// dead-letter producer
+func NewConsumer(config *Config, handler Handler) (*Consumer, error) {
+	saramaConfig := sarama.NewConfig()
+	saramaConfig.ClientID = config.GroupID
+	saramaConfig.Consumer.Offsets.Initial = sarama.OffsetOldest
+	saramaConfig.Consumer.Offsets.AutoCommit.Enable = true
+	saramaConfig.Producer.RequiredAcks = sarama.WaitForAll
+	saramaConfig.Producer.Return.Successes = true
+
+	group, err := sarama.NewConsumerGroup(config.Brokers, config.GroupID, saramaConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create consumer group: %w", err)
+	}
+
+	producer, err := sarama.NewSyncProducer(config.Brokers, saramaConfig)
+	if err != nil {
+		group.Close()
+		return nil, fmt.Errorf("failed to create dead-letter producer: %w", err)
+	}
+
+	return NewConsumerWithClients(config, group, producer, handler), nil
+}
+
+// NewConsumerWithClients creates a consumer from existing clients
+func NewConsumerWithClients(config *Config, group sarama.ConsumerGroup, producer sarama.SyncProducer, handler Handler) *Consumer {
+	return &Consumer{
+		group:    group,
+		producer: producer,
+		handler:  handler,
+		config:   config,
+		tracer:   otel.Tracer("payments.kafka"),
+	}
+}
+
+// UseRecorder records every dead-lettered message with recorder as well
+func (c *Consumer) UseRecorder(recorder Recorder) {
+	c.recorder = recorder
+}
+
+// Start consumes in the background until Close is called
+func (c *Consumer) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	c.stopped = make(chan struct{})
+
+	go func() {
+		defer close(c.stopped)
+		for {
+			// Consume returns at each rebalance and must be called again
+			err := c.group.Consume(ctx, c.config.Topics, c)
+			if errors.Is(err, sarama.ErrClosedConsumerGroup) || ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				log.Printf("Kafka consumer session failed: %v", err)
+				select {
+				case <-time.After(c.config.backoff(1)):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+}
+
+// Close stops consuming, letting the message being handled finish, then
+// commits marked offsets and leaves the group so its partitions are
+// reassigned. Messages not yet settled are redelivered to the next owner.
+func (c *Consumer) Close(ctx context.Context) error {
+	var errs []error
+	if c.cancel != nil {
+		c.cancel()
+		select {
+		case <-c.stopped:
+		case <-ctx.Done():
+			errs = append(errs, fmt.Errorf("consumer did not stop: %w", ctx.Err()))
+		}
+	}
+
+	if err := c.group.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to leave consumer group: %w", err))
+	}
+	if err := c.producer.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to close dead-letter producer: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Setup is called when a session begins
+func (c *Consumer) Setup(session sarama.ConsumerGroupSession) error {
+	log.Printf("Kafka consumer joined group %s with claims %v", c.config.GroupID, session.Claims())
+	return nil
+}
+
+// Cleanup is called when a session ends, after every claim has returned
+func (c *Consumer) Cleanup(session sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// ConsumeClaim handles a partition's messages in order until the session ends
+func (c *Consumer) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for {
+		select {
+		case message, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
+			if !c.process(session.Context(), message) {
+				// Unmarked, so the message is redelivered to the next owner
+				return nil
+			}
+			session.MarkMessage(message, "")
+		case <-session.Context().Done():
+			return nil
+		}
+	}
+}
+
+// process handles a message, retrying with backoff and dead-lettering it
+// once attempts run out. It reports false when the session ended before the
+// message was settled.
+func (c *Consumer) process(ctx context.Context, message *sarama.ConsumerMessage) bool {
+	msg := convertMessage(message)
+
+	ctx, span := c.tracer.Start(ctx, "Consumer.Handle", trace.WithAttributes(
+		attribute.String("messaging.destination", msg.Topic),
+		attribute.Int64("messaging.kafka.partition", int64(msg.Partition)),
+		attribute.Int64("messaging.kafka.offset", msg.Offset),
+	))
+	defer span.End()
+
+	var err error
+	attempts := 0
+	for attempts < c.config.MaxAttempts {
+		if attempts > 0 && !sleep(ctx, c.config.backoff(attempts)) {
+			return false
+		}
+		attempts++
+
+		// A handler that has started, e.g. issuing a refund, is allowed to
+		// finish even when shutdown begins
+		err = c.handler.Handle(context.WithoutCancel(ctx), msg)
+		if err == nil {
+			return true
+		}
+		if errors.Is(err, ErrPermanent) {
+			break
+		}
+		log.Printf("Kafka message %s/%d/%d failed attempt %d: %v", msg.Topic, msg.Partition, msg.Offset, attempts, err)
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+
+	return c.deadLetter(ctx, message, err, attempts)
+}
+
+// deadLetter publishes a failed message to the dead-letter topic with the
+// reason and its origin, retrying until it is stored or the session ends
+func (c *Consumer) deadLetter(ctx context.Context, message *sarama.ConsumerMessage, cause error, attempts int) bool {
+	dead := &sarama.ProducerMessage{
+		Topic: c.config.DLQTopic,
+		Key:   sarama.ByteEncoder(message.Key),
+		Value: sarama.ByteEncoder(message.Value),
+	}
+	for _, header := range message.Headers {
+		dead.Headers = append(dead.Headers, *header)
+	}
+	dead.Headers = append(dead.Headers,
+		recordHeader(HeaderError, cause.Error()),
+		recordHeader(HeaderTopic, message.Topic),
+		recordHeader(HeaderPartition, strconv.Itoa(int(message.Partition))),
+		recordHeader(HeaderOffset, strconv.FormatInt(message.Offset, 10)),
+		recordHeader(HeaderAttempts, strconv.Itoa(attempts)),
+	)
+
+	for retry := 1; ; retry++ {
+		_, _, err := c.producer.SendMessage(dead)
+		if err == nil {
+			log.Printf("Kafka message %s/%d/%d dead-lettered to %s: %v", message.Topic, message.Partition, message.Offset, c.config.DLQTopic, cause)
+			c.record(ctx, message, cause, attempts)
+			return true
+		}
+		log.Printf("Failed to dead-letter Kafka message %s/%d/%d: %v", message.Topic, message.Partition, message.Offset, err)
+
+		if !sleep(ctx, c.config.backoff(retry)) {
+			return false
+		}
+	}
+}
+
+// record hands a dead-lettered message to the recorder. The topic already
+// holds it, so a failure to record is only logged.
+func (c *Consumer) record(ctx context.Context, message *sarama.ConsumerMessage, cause error, attempts int) {
+	if c.recorder == nil {
+		return
+	}
+	if err := c.recorder.RecordDeadLetter(context.WithoutCancel(ctx), convertMessage(message), cause, attempts); err != nil {
+		log.Printf("Failed to record dead-lettered Kafka message %s/%d/%d: %v", message.Topic, message.Partition, message.Offset, err)
+	}
+}
+
+// sleep waits for a delay, reporting false if the context ends first
+func sleep(ctx context.Context, delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// recordHeader builds a Kafka record header
+func recordHeader(key, value string) sarama.RecordHeader {
+	return sarama.RecordHeader{Key: []byte(key), Value: []byte(value)}
+}
+
+// convertMessage converts a consumed sarama message to our Message type
+func convertMessage(message *sarama.ConsumerMessage) *Message {
+	headers := make(map[string]string, len(message.Headers))
+	for _, header := range message.Headers {
+		headers[string(header.Key)] = string(header.Value)
+	}
+
+	return &Message{
+		Topic:     message.Topic,
+		Partition: message.Partition,
+		Offset:    message.Offset,
+		Key:       message.Key,
+		Value:     message.Value,
+		Headers:   headers,
+		Timestamp: message.Timestamp,
+	}
+}
@@ -0,0 +1,137 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrPermanent marks handler errors that retrying cannot fix, such as
+// malformed messages. Messages failing with it go to the dead-letter topic
+// without further attempts.
+var ErrPermanent = errors.New("permanent failure")
+
+// Headers set on messages routed to the dead-letter topic
+const (
+	HeaderError     = "dlq-error"
+	HeaderTopic     = "dlq-original-topic"
+	HeaderPartition = "dlq-original-partition"
+	HeaderOffset    = "dlq-original-offset"
+	HeaderAttempts  = "dlq-attempts"
+)
+
+// Message is a message read from a topic
+type Message struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+	Key       []byte
+	Value     []byte
+	Headers   map[string]string
+	Timestamp time.Time
+}
+
+// Handler processes consumed messages. A message is marked consumed once
+// Handle returns nil or the message has been dead-lettered.
+type Handler interface {
+	Handle(ctx context.Context, message *Message) error
+}
+
+// HandlerFunc adapts a function to a Handler
+type HandlerFunc func(ctx context.Context, message *Message) error
+
+// Handle calls f
+func (f HandlerFunc) Handle(ctx context.Context, message *Message) error {
+	return f(ctx, message)
+}
+
+// Recorder durably records dead-lettered messages alongside the dead-letter
+// topic, so they can be inspected and retried without consuming the topic
+type Recorder interface {
+	RecordDeadLetter(ctx context.Context, message *Message, cause error, attempts int) error
+}
+
+// Config configures the consumer group and the events publisher
+type Config struct {
+	Enabled       bool
+	Brokers       []string
+	GroupID       string
+	Topics        []string
+	DLQTopic      string        // Messages that fail every attempt are published here
+	MaxAttempts   int           // Attempts per message before it is dead-lettered
+	Backoff       time.Duration // Delay before the first retry, doubled on each retry
+	MaxBackoff    time.Duration
+	EventsEnabled bool   // Publish emitted events to Kafka rather than logging them
+	EventsTopic   string // Topic emitted events are published to
+}
+
+// LoadConfig loads the consumer and publisher configuration from environment variables
+func LoadConfig() *Config {
+	config := &Config{
+		Enabled:     false,
+		Brokers:     []string{"localhost:9092"},
+		GroupID:     "payments",
+		Topics:      []string{"payment-commands"},
+		DLQTopic:    "payment-commands.dlq",
+		MaxAttempts: 5,
+		Backoff:     500 * time.Millisecond,
+		MaxBackoff:  30 * time.Second,
+		EventsTopic: "payment-events",
+	}
+
+	if enabled, err := strconv.ParseBool(os.Getenv("KAFKA_CONSUMER_ENABLED")); err == nil {
+		config.Enabled = enabled
+	}
+	if enabled, err := strconv.ParseBool(os.Getenv("KAFKA_EVENTS_ENABLED")); err == nil {
+		config.EventsEnabled = enabled
+	}
+	if topic := os.Getenv("KAFKA_EVENTS_TOPIC"); topic != "" {
+		config.EventsTopic = topic
+	}
+	if brokers := splitList(os.Getenv("KAFKA_BROKERS")); len(brokers) > 0 {
+		config.Brokers = brokers
+	}
+	if group := os.Getenv("KAFKA_CONSUMER_GROUP"); group != "" {
+		config.GroupID = group
+	}
+	if topics := splitList(os.Getenv("KAFKA_COMMAND_TOPICS")); len(topics) > 0 {
+		config.Topics = topics
+	}
+	if topic := os.Getenv("KAFKA_DLQ_TOPIC"); topic != "" {
+		config.DLQTopic = topic
+	}
+	if attempts, err := strconv.Atoi(os.Getenv("KAFKA_MAX_ATTEMPTS")); err == nil && attempts > 0 {
+		config.MaxAttempts = attempts
+	}
+	if ms, err := strconv.Atoi(os.Getenv("KAFKA_RETRY_BACKOFF_MS")); err == nil && ms > 0 {
+		config.Backoff = time.Duration(ms) * time.Millisecond
+	}
+
+	return config
+}
+
+// backoff returns the delay before the given retry, starting at 1
+func (c *Config) backoff(retry int) time.Duration {
+	delay := c.Backoff
+	for i := 1; i < retry && delay < c.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if c.MaxBackoff > 0 && delay > c.MaxBackoff {
+		delay = c.MaxBackoff
+	}
+	return delay
+}
+
+// splitList splits a comma-separated list, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,95 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"apis/payments/services/events"
+
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// CloudEventsContentType marks messages holding a structured-mode CloudEvent
+const CloudEventsContentType = "application/cloudevents+json"
+
+// Publisher publishes CloudEvents to the events topic in structured mode.
+// Messages are keyed by the event's subject, so the events of one charge,
+// refund or dispute stay in order on one partition.
+type Publisher struct {
+	producer sarama.SyncProducer
+	topic    string
+	tracer   trace.Tracer
+}
+
+// NewPublisher connects a producer to the configured brokers
+func NewPublisher(config *Config) (*Publisher, error) {
+	saramaConfig := sarama.NewConfig()
+	saramaConfig.ClientID = config.GroupID
+	saramaConfig.Producer.RequiredAcks = sarama.WaitForAll
+	saramaConfig.Producer.Return.Successes = true
+
+	producer, err := sarama.NewSyncProducer(config.Brokers, saramaConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create event producer: %w", err)
+	}
+
+	return NewPublisherWithProducer(config, producer), nil
+}
+
+// NewPublisherWithProducer creates a publisher from an existing producer
+func NewPublisherWithProducer(config *Config, producer sarama.SyncProducer) *Publisher {
+	return &Publisher{
+		producer: producer,
+		topic:    config.EventsTopic,
+		tracer:   otel.Tracer("payments.kafka"),
+	}
+}
+
+// Publish implements events.Publisher, returning once the brokers have
+// acknowledged the event
+func (p *Publisher) Publish(ctx context.Context, event *events.Event) error {
+	return p.PublishTo(ctx, p.topic, event)
+}
+
+// PublishTo publishes an event to another topic, such as when replaying
+// archived events
+func (p *Publisher) PublishTo(ctx context.Context, topic string, event *events.Event) error {
+	_, span := p.tracer.Start(ctx, "PublishEvent", trace.WithAttributes(
+		attribute.String("messaging.destination", topic),
+		attribute.String("cloudevents.event_type", event.Type),
+	))
+	defer span.End()
+
+	value, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event %s: %w", event.ID, err)
+	}
+
+	message := &sarama.ProducerMessage{
+		Topic: topic,
+		Value: sarama.ByteEncoder(value),
+		Headers: []sarama.RecordHeader{
+			recordHeader("content-type", CloudEventsContentType),
+		},
+	}
+	if event.Subject != "" {
+		message.Key = sarama.StringEncoder(event.Subject)
+	}
+
+	if _, _, err := p.producer.SendMessage(message); err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to publish event %s to %s: %w", event.ID, topic, err)
+	}
+
+	return nil
+}
+
+// Close closes the producer
+func (p *Publisher) Close() error {
+	return p.producer.Close()
+}
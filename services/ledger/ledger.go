@@ -0,0 +1,149 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Accounts
+const (
+	// AccountProviderBalance holds funds at the payment provider
+	AccountProviderBalance = "provider_balance"
+	// AccountUnclaimedFunds holds customer funds received but not applied to a payment
+	AccountUnclaimedFunds = "unclaimed_funds"
+	// AccountReceivable holds amounts invoiced to customers but not yet paid
+	AccountReceivable = "accounts_receivable"
+	// AccountRevenue records invoiced revenue
+	AccountRevenue = "revenue"
+	// AccountBank holds funds received outside the provider, e.g. offline bank transfers
+	AccountBank = "bank"
+	// AccountBadDebt records invoices written off as uncollectible
+	AccountBadDebt = "bad_debt"
+	// AccountMerchantReceivable holds card payments settled by the provider
+	// and owed to the merchant until they are paid out
+	AccountMerchantReceivable = "merchant_receivable"
+	// AccountProviderFees records processing and dispute fees charged by the provider
+	AccountProviderFees = "provider_fees"
+	// AccountReserve holds disputed funds withdrawn by the provider until the dispute closes
+	AccountReserve = "reserve"
+	// AccountRefunds records payments returned to customers
+	AccountRefunds = "refunds"
+	// AccountChargebacks records disputed payments lost to the cardholder
+	AccountChargebacks = "chargebacks"
+)
+
+// Account types
+const (
+	AccountTypeAsset         = "asset"
+	AccountTypeLiability     = "liability"
+	AccountTypeRevenue       = "revenue"
+	AccountTypeContraRevenue = "contra_revenue"
+	AccountTypeExpense       = "expense"
+)
+
+// Account is a ledger account. Debit-normal accounts (assets, expenses and
+// contra-revenue) grow with debits; the rest grow with credits.
+type Account struct {
+	ID          string `json:"id"`
+	Type        string `json:"type"`
+	NormalDebit bool   `json:"normal_debit"`
+	Description string `json:"description"`
+}
+
+// accounts lists every account entries are posted to
+var accounts = []Account{
+	{ID: AccountProviderBalance, Type: AccountTypeAsset, NormalDebit: true, Description: "Funds at the payment provider"},
+	{ID: AccountMerchantReceivable, Type: AccountTypeAsset, NormalDebit: true, Description: "Settled payments owed to the merchant until paid out"},
+	{ID: AccountReserve, Type: AccountTypeAsset, NormalDebit: true, Description: "Disputed funds held by the provider"},
+	{ID: AccountReceivable, Type: AccountTypeAsset, NormalDebit: true, Description: "Invoiced amounts not yet paid"},
+	{ID: AccountBank, Type: AccountTypeAsset, NormalDebit: true, Description: "Funds in the merchant's bank account"},
+	{ID: AccountUnclaimedFunds, Type: AccountTypeLiability, Description: "Customer funds not applied to a payment"},
+	{ID: AccountRevenue, Type: AccountTypeRevenue, Description: "Captured payments and invoiced revenue"},
+	{ID: AccountRefunds, Type: AccountTypeContraRevenue, NormalDebit: true, Description: "Payments returned to customers"},
+	{ID: AccountProviderFees, Type: AccountTypeExpense, NormalDebit: true, Description: "Processing and dispute fees"},
+	{ID: AccountChargebacks, Type: AccountTypeExpense, NormalDebit: true, Description: "Disputes lost to the cardholder"},
+	{ID: AccountBadDebt, Type: AccountTypeExpense, NormalDebit: true, Description: "Invoices written off as uncollectible"},
+}
+
+// Accounts returns every ledger account
+func Accounts() []Account {
+	return append([]Account(nil), accounts...)
+}
+
+// LookupAccount returns the account with an ID
+func LookupAccount(id string) (Account, bool) {
+	for _, account := range accounts {
+		if account.ID == id {
+			return account, true
+		}
+	}
+	return Account{}, false
+}
+
+// Reference types for entries posted from provider events
+const (
+	ReferenceCharge  = "charge"
+	ReferenceRefund  = "refund"
+	ReferenceDispute = "dispute"
+	ReferencePayout  = "payout"
+)
+
+// ErrInvalidEntry is returned for entries that cannot be posted
+var ErrInvalidEntry = errors.New("invalid ledger entry")
+
+// ErrUnknownAccount is returned when querying an account that does not exist
+var ErrUnknownAccount = errors.New("unknown ledger account")
+
+// Entry moves an amount from the credit account to the debit account. Every
+// entry is balanced by construction.
+type Entry struct {
+	ID            string    `json:"id"`
+	DebitAccount  string    `json:"debit_account"`
+	CreditAccount string    `json:"credit_account"`
+	Amount        int64     `json:"amount"`
+	Currency      string    `json:"currency"`
+	ReferenceType string    `json:"reference_type"` // e.g. "auto_refund"
+	ReferenceID   string    `json:"reference_id"`
+	Description   string    `json:"description,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// AccountTotal is the sum of the debits and credits posted to an account in
+// one currency
+type AccountTotal struct {
+	Account  string
+	Currency string
+	Debits   int64
+	Credits  int64
+}
+
+// AccountBalance is an account's totals in a trial balance. Balance is on
+// the account's normal side, so it is negative when the account is overdrawn.
+type AccountBalance struct {
+	Account string `json:"account"`
+	Type    string `json:"type"`
+	Debits  int64  `json:"debits"`
+	Credits int64  `json:"credits"`
+	Balance int64  `json:"balance"`
+}
+
+// TrialBalance lists the account balances in one currency. Total debits
+// equal total credits unless entries have been altered outside the ledger.
+type TrialBalance struct {
+	Currency     string            `json:"currency"`
+	Accounts     []*AccountBalance `json:"accounts"`
+	TotalDebits  int64             `json:"total_debits"`
+	TotalCredits int64             `json:"total_credits"`
+	Balanced     bool              `json:"balanced"`
+	AsOf         time.Time         `json:"as_of"`
+}
+
+// Store persists ledger entries. Posting the same reference and accounts
+// twice must not create a second entry.
+type Store interface {
+	CreateLedgerEntry(ctx context.Context, entry *Entry) error
+	ListLedgerEntriesByReference(ctx context.Context, referenceType, referenceID string) ([]*Entry, error)
+	ListLedgerEntriesByAccount(ctx context.Context, account, currency string, limit, offset int) ([]*Entry, error)
+	ListLedgerAccountTotals(ctx context.Context, asOf time.Time) ([]*AccountTotal, error)
+}
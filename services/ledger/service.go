@@ -0,0 +1,121 @@
+package ledger
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Page sizes for account entry listings
+const (
+	defaultListLimit = 50
+	maxListLimit     = 500
+)
+
+// Service posts ledger entries
+type Service struct {
+	store  Store
+	tracer trace.Tracer
+}
+
+// NewService creates a new ledger service
+func NewService(store Store) *Service {
+	return &Service{
+		store:  store,
+		tracer: otel.Tracer("payments.ledger"),
+	}
+}
+
+// Post records an entry. Reposting an entry for the same reference is a no-op.
+func (s *Service) Post(ctx context.Context, entry *Entry) error {
+	ctx, span := s.tracer.Start(ctx, "Post")
+	defer span.End()
+
+	if entry.Amount <= 0 {
+		return fmt.Errorf("%w: amount must be positive", ErrInvalidEntry)
+	}
+	if entry.DebitAccount == "" || entry.CreditAccount == "" || entry.DebitAccount == entry.CreditAccount {
+		return fmt.Errorf("%w: debit and credit accounts must be set and differ", ErrInvalidEntry)
+	}
+	if entry.Currency == "" || entry.ReferenceType == "" || entry.ReferenceID == "" {
+		return fmt.Errorf("%w: currency and reference are required", ErrInvalidEntry)
+	}
+	entry.Currency = strings.ToLower(entry.Currency)
+
+	return s.store.CreateLedgerEntry(ctx, entry)
+}
+
+// EntriesFor returns the entries posted for a reference
+func (s *Service) EntriesFor(ctx context.Context, referenceType, referenceID string) ([]*Entry, error) {
+	ctx, span := s.tracer.Start(ctx, "EntriesFor")
+	defer span.End()
+
+	return s.store.ListLedgerEntriesByReference(ctx, referenceType, referenceID)
+}
+
+// AccountEntries returns the entries debiting or crediting an account, newest
+// first, optionally in one currency
+func (s *Service) AccountEntries(ctx context.Context, account, currency string, limit, offset int) ([]*Entry, error) {
+	ctx, span := s.tracer.Start(ctx, "AccountEntries")
+	defer span.End()
+
+	if _, ok := LookupAccount(account); !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownAccount, account)
+	}
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+
+	return s.store.ListLedgerEntriesByAccount(ctx, account, strings.ToLower(currency), min(limit, maxListLimit), max(offset, 0))
+}
+
+// TrialBalance totals every account per currency for entries posted up to asOf
+func (s *Service) TrialBalance(ctx context.Context, asOf time.Time) ([]*TrialBalance, error) {
+	ctx, span := s.tracer.Start(ctx, "TrialBalance")
+	defer span.End()
+
+	totals, err := s.store.ListLedgerAccountTotals(ctx, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	byCurrency := make(map[string]*TrialBalance)
+	for _, total := range totals {
+		balance, ok := byCurrency[total.Currency]
+		if !ok {
+			balance = &TrialBalance{Currency: total.Currency, AsOf: asOf}
+			byCurrency[total.Currency] = balance
+		}
+
+		accountBalance := &AccountBalance{
+			Account: total.Account,
+			Debits:  total.Debits,
+			Credits: total.Credits,
+			Balance: total.Debits - total.Credits,
+		}
+		if account, ok := LookupAccount(total.Account); ok {
+			accountBalance.Type = account.Type
+			if !account.NormalDebit {
+				accountBalance.Balance = -accountBalance.Balance
+			}
+		}
+
+		balance.Accounts = append(balance.Accounts, accountBalance)
+		balance.TotalDebits += total.Debits
+		balance.TotalCredits += total.Credits
+	}
+
+	balances := make([]*TrialBalance, 0, len(byCurrency))
+	for _, balance := range byCurrency {
+		balance.Balanced = balance.TotalDebits == balance.TotalCredits
+		balances = append(balances, balance)
+	}
+	sort.Slice(balances, func(i, j int) bool { return balances[i].Currency < balances[j].Currency })
+
+	return balances, nil
+}
@@ -0,0 +1,207 @@
+package ledger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"apis/payments/services/stripe"
+
+	"github.com/google/uuid"
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// BalanceTransactions looks up what the provider kept from a payment
+type BalanceTransactions interface {
+	GetBalanceTransaction(ctx context.Context, transactionID string) (*stripe.BalanceTransaction, error)
+}
+
+// RegisterWebhookHandlers posts captured charges, refunds, disputes, payouts
+// and the provider's fees on them. Events from connected accounts are not
+// posted since those funds belong to the connected account. Fees are posted
+// in the settlement currency, which may differ from the charge currency.
+func (s *Service) RegisterWebhookHandlers(webhooks *stripe.WebhookService, balances BalanceTransactions) {
+	for _, eventType := range []stripego.EventType{
+		stripego.EventTypeChargeSucceeded,
+		stripego.EventTypeChargeCaptured,
+	} {
+		webhooks.On(eventType, func(ctx context.Context, event stripego.Event) error {
+			if event.Account != "" {
+				return nil
+			}
+			var charge stripego.Charge
+			if err := json.Unmarshal(event.Data.Raw, &charge); err != nil {
+				return fmt.Errorf("failed to parse charge: %w", err)
+			}
+			return s.postCharge(ctx, &charge, balances)
+		})
+	}
+
+	for _, eventType := range []stripego.EventType{
+		stripego.EventTypeRefundCreated,
+		stripego.EventTypeRefundUpdated,
+		stripego.EventTypeChargeRefundUpdated,
+	} {
+		webhooks.On(eventType, func(ctx context.Context, event stripego.Event) error {
+			if event.Account != "" {
+				return nil
+			}
+			var refund stripego.Refund
+			if err := json.Unmarshal(event.Data.Raw, &refund); err != nil {
+				return fmt.Errorf("failed to parse refund: %w", err)
+			}
+			return s.postRefund(ctx, &refund)
+		})
+	}
+
+	for _, eventType := range []stripego.EventType{
+		stripego.EventTypeChargeDisputeCreated,
+		stripego.EventTypeChargeDisputeFundsWithdrawn,
+		stripego.EventTypeChargeDisputeClosed,
+	} {
+		webhooks.On(eventType, func(ctx context.Context, event stripego.Event) error {
+			if event.Account != "" {
+				return nil
+			}
+			var dispute stripego.Dispute
+			if err := json.Unmarshal(event.Data.Raw, &dispute); err != nil {
+				return fmt.Errorf("failed to parse dispute: %w", err)
+			}
+			return s.postDispute(ctx, &dispute)
+		})
+	}
+
+	for _, eventType := range []stripego.EventType{
+		stripego.EventTypePayoutPaid,
+		stripego.EventTypePayoutFailed,
+	} {
+		webhooks.On(eventType, func(ctx context.Context, event stripego.Event) error {
+			if event.Account != "" {
+				return nil
+			}
+			var payout stripego.Payout
+			if err := json.Unmarshal(event.Data.Raw, &payout); err != nil {
+				return fmt.Errorf("failed to parse payout: %w", err)
+			}
+			return s.postPayout(ctx, &payout)
+		})
+	}
+}
+
+// postCharge posts a captured charge as revenue owed by the provider, and
+// the processing fee the provider kept from it
+func (s *Service) postCharge(ctx context.Context, charge *stripego.Charge, balances BalanceTransactions) error {
+	if !charge.Captured || charge.AmountCaptured <= 0 {
+		return nil
+	}
+
+	if err := s.post(ctx, AccountMerchantReceivable, AccountRevenue, charge.AmountCaptured, string(charge.Currency),
+		ReferenceCharge, charge.ID, "Captured charge"); err != nil {
+		return err
+	}
+
+	if charge.BalanceTransaction == nil || charge.BalanceTransaction.ID == "" || balances == nil {
+		return nil
+	}
+	transaction, err := balances.GetBalanceTransaction(ctx, charge.BalanceTransaction.ID)
+	if err != nil {
+		return fmt.Errorf("failed to look up fee for charge %s: %w", charge.ID, err)
+	}
+
+	return s.post(ctx, AccountProviderFees, AccountMerchantReceivable, transaction.Fee, transaction.Currency,
+		ReferenceCharge, charge.ID, "Processing fee")
+}
+
+// postRefund posts a refund once it succeeds, reversing it if it later fails
+func (s *Service) postRefund(ctx context.Context, refund *stripego.Refund) error {
+	switch refund.Status {
+	case stripego.RefundStatusSucceeded:
+		return s.post(ctx, AccountRefunds, AccountMerchantReceivable, refund.Amount, string(refund.Currency),
+			ReferenceRefund, refund.ID, "Refund")
+	case stripego.RefundStatusFailed, stripego.RefundStatusCanceled:
+		return s.reverse(ctx, AccountRefunds, AccountMerchantReceivable, ReferenceRefund, refund.ID, "Refund reversed")
+	}
+	return nil
+}
+
+// postDispute moves disputed funds into the reserve once the provider
+// withdraws them, along with the dispute fee, and out again when the dispute
+// closes. Inquiries withdraw nothing, so they are not posted.
+func (s *Service) postDispute(ctx context.Context, dispute *stripego.Dispute) error {
+	if len(dispute.BalanceTransactions) == 0 {
+		return nil
+	}
+
+	if err := s.post(ctx, AccountReserve, AccountMerchantReceivable, dispute.Amount, string(dispute.Currency),
+		ReferenceDispute, dispute.ID, "Disputed funds withdrawn"); err != nil {
+		return err
+	}
+
+	fees := make(map[string]int64)
+	for _, transaction := range dispute.BalanceTransactions {
+		fees[string(transaction.Currency)] += transaction.Fee
+	}
+	for currency, fee := range fees {
+		if err := s.post(ctx, AccountProviderFees, AccountMerchantReceivable, fee, currency,
+			ReferenceDispute, dispute.ID, "Dispute fee"); err != nil {
+			return err
+		}
+	}
+
+	switch dispute.Status {
+	case stripego.DisputeStatusWon:
+		return s.post(ctx, AccountMerchantReceivable, AccountReserve, dispute.Amount, string(dispute.Currency),
+			ReferenceDispute, dispute.ID, "Dispute won, funds reinstated")
+	case stripego.DisputeStatusLost:
+		return s.post(ctx, AccountChargebacks, AccountReserve, dispute.Amount, string(dispute.Currency),
+			ReferenceDispute, dispute.ID, "Dispute lost")
+	}
+	return nil
+}
+
+// postPayout posts a paid payout to the bank, reversing it if the bank
+// later returns it
+func (s *Service) postPayout(ctx context.Context, payout *stripego.Payout) error {
+	switch payout.Status {
+	case stripego.PayoutStatusPaid:
+		return s.post(ctx, AccountBank, AccountMerchantReceivable, payout.Amount, string(payout.Currency),
+			ReferencePayout, payout.ID, "Payout")
+	case stripego.PayoutStatusFailed, stripego.PayoutStatusCanceled:
+		return s.reverse(ctx, AccountBank, AccountMerchantReceivable, ReferencePayout, payout.ID, "Payout returned")
+	}
+	return nil
+}
+
+// post posts an entry for a provider object, skipping zero amounts such as
+// fee-free charges
+func (s *Service) post(ctx context.Context, debit, credit string, amount int64, currency, referenceType, referenceID, description string) error {
+	if amount <= 0 {
+		return nil
+	}
+
+	return s.Post(ctx, &Entry{
+		ID:            fmt.Sprintf("le_%s", uuid.New().String()),
+		DebitAccount:  debit,
+		CreditAccount: credit,
+		Amount:        amount,
+		Currency:      currency,
+		ReferenceType: referenceType,
+		ReferenceID:   referenceID,
+		Description:   description,
+	})
+}
+
+// reverse posts the opposite of an earlier entry, if one was posted
+func (s *Service) reverse(ctx context.Context, debit, credit, referenceType, referenceID, description string) error {
+	entries, err := s.EntriesFor(ctx, referenceType, referenceID)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.DebitAccount == debit && entry.CreditAccount == credit {
+			return s.post(ctx, credit, debit, entry.Amount, entry.Currency, referenceType, referenceID, description)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package merchantwebhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers sent with every delivery
+const (
+	SignatureHeader  = "X-Payments-Signature"
+	EventIDHeader    = "X-Payments-Event-ID"
+	EventTypeHeader  = "X-Payments-Event-Type"
+	DeliveryIDHeader = "X-Payments-Delivery-ID"
+)
+
+// Delivery statuses
+const (
+	StatusPending   = "pending"   // Waiting for its next attempt
+	StatusSucceeded = "succeeded" // Accepted by the endpoint
+	StatusFailed    = "failed"    // Out of attempts or undeliverable; redeliver manually
+)
+
+var (
+	// ErrInvalidEndpoint is returned for endpoints with an unusable URL or
+	// event types
+	ErrInvalidEndpoint = errors.New("invalid webhook endpoint")
+	// ErrPrivateAddress is returned when a delivery would connect to a
+	// private, loopback or link-local address
+	ErrPrivateAddress = errors.New("webhook endpoint resolves to a private address")
+	// ErrInvalidSignature is returned when a delivery's signature does not
+	// match its payload, or is too old
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+)
+
+// Endpoint is a URL a tenant receives events at. Its secret is only
+// returned when the endpoint is created.
+type Endpoint struct {
+	ID          string    `json:"id"`
+	TenantID    string    `json:"tenant_id"`
+	URL         string    `json:"url"`
+	Description string    `json:"description,omitempty"`
+	EventTypes  []string  `json:"event_types"` // Exact types, prefixes such as payments.charge.*, or * for every event
+	Enabled     bool      `json:"enabled"`
+	Secret      string    `json:"secret,omitempty"`
+	Ciphertext  []byte    `json:"-"` // The encrypted secret
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Subscribes reports whether the endpoint receives events of a type
+func (e *Endpoint) Subscribes(eventType string) bool {
+	for _, pattern := range e.EventTypes {
+		if pattern == "*" || pattern == eventType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(eventType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateEndpointRequest registers an endpoint
+type CreateEndpointRequest struct {
+	URL         string   `json:"url"`
+	Description string   `json:"description"`
+	EventTypes  []string `json:"event_types"` // Every event when empty
+}
+
+// UpdateEndpointRequest changes an endpoint. Unset fields are kept.
+type UpdateEndpointRequest struct {
+	URL         *string  `json:"url,omitempty"`
+	Description *string  `json:"description,omitempty"`
+	EventTypes  []string `json:"event_types,omitempty"`
+	Enabled     *bool    `json:"enabled,omitempty"`
+}
+
+// Delivery is an event queued for an endpoint
+type Delivery struct {
+	ID             string     `json:"id"`
+	TenantID       string     `json:"tenant_id"`
+	EndpointID     string     `json:"endpoint_id"`
+	EventID        string     `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Payload        []byte     `json:"-"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"` // Automatic attempts made
+	LastStatusCode int        `json:"last_status_code,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Log holds every attempt made, oldest first, when the delivery is read
+	// on its own
+	Log []*Attempt `json:"attempts_log,omitempty"`
+}
+
+// Attempt is one try at sending a delivery
+type Attempt struct {
+	ID          string    `json:"id"`
+	DeliveryID  string    `json:"delivery_id"`
+	StatusCode  int       `json:"status_code,omitempty"` // 0 when no response was received
+	Error       string    `json:"error,omitempty"`
+	DurationMS  int64     `json:"duration_ms"`
+	Manual      bool      `json:"manual"` // Made by a redeliver request
+	AttemptedAt time.Time `json:"attempted_at"`
+}
+
+// DeliveryResult summarizes a delivery run
+type DeliveryResult struct {
+	Attempted int `json:"attempted"`
+	Succeeded int `json:"succeeded"`
+	Retrying  int `json:"retrying"`
+	Failed    int `json:"failed"`
+}
+
+// Config controls deliveries
+type Config struct {
+	Enabled     bool
+	Interval    time.Duration // How often due deliveries are sent
+	MaxAttempts int           // Automatic attempts before a delivery fails
+	Backoff     time.Duration // Delay before the second attempt, doubled after each failure
+	MaxBackoff  time.Duration
+	Timeout     time.Duration // How long an endpoint has to respond
+	BatchSize   int           // Deliveries sent per run
+	AllowHTTP   bool          // Accept plain http endpoint URLs, for local development
+
+	// AllowPrivateNetworks lets endpoints point at private, loopback and
+	// link-local addresses, for local development
+	AllowPrivateNetworks bool
+}
+
+// LoadConfig loads the delivery configuration from environment variables
+func LoadConfig() *Config {
+	config := &Config{
+		Enabled:     true,
+		Interval:    5 * time.Second,
+		MaxAttempts: 10,
+		Backoff:     30 * time.Second,
+		MaxBackoff:  12 * time.Hour,
+		Timeout:     10 * time.Second,
+		BatchSize:   50,
+	}
+
+	if enabled, err := strconv.ParseBool(os.Getenv("WEBHOOK_DELIVERY_ENABLED")); err == nil {
+		config.Enabled = enabled
+	}
+	if seconds, err := strconv.Atoi(os.Getenv("WEBHOOK_DELIVERY_INTERVAL_SECONDS")); err == nil && seconds > 0 {
+		config.Interval = time.Duration(seconds) * time.Second
+	}
+	if attempts, err := strconv.Atoi(os.Getenv("WEBHOOK_DELIVERY_MAX_ATTEMPTS")); err == nil && attempts > 0 {
+		config.MaxAttempts = attempts
+	}
+	if seconds, err := strconv.Atoi(os.Getenv("WEBHOOK_DELIVERY_BACKOFF_SECONDS")); err == nil && seconds > 0 {
+		config.Backoff = time.Duration(seconds) * time.Second
+	}
+	if seconds, err := strconv.Atoi(os.Getenv("WEBHOOK_DELIVERY_MAX_BACKOFF_SECONDS")); err == nil && seconds > 0 {
+		config.MaxBackoff = time.Duration(seconds) * time.Second
+	}
+	if seconds, err := strconv.Atoi(os.Getenv("WEBHOOK_DELIVERY_TIMEOUT_SECONDS")); err == nil && seconds > 0 {
+		config.Timeout = time.Duration(seconds) * time.Second
+	}
+	if allow, err := strconv.ParseBool(os.Getenv("WEBHOOK_ENDPOINTS_ALLOW_HTTP")); err == nil {
+		config.AllowHTTP = allow
+	}
+	if allow, err := strconv.ParseBool(os.Getenv("WEBHOOK_ENDPOINTS_ALLOW_PRIVATE_NETWORKS")); err == nil {
+		config.AllowPrivateNetworks = allow
+	}
+	if config.MaxBackoff < config.Backoff {
+		config.MaxBackoff = config.Backoff
+	}
+
+	return config
+}
+
+// backoff returns the delay before the attempt following a number of failed ones
+func (c *Config) backoff(failures int) time.Duration {
+	delay := c.Backoff
+	for i := 1; i < failures && delay < c.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > c.MaxBackoff {
+		delay = c.MaxBackoff
+	}
+	return delay
+}
+
+// Sign returns the signature header for a payload sent at a time:
+// t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<payload>" keyed by the secret>
+func Sign(secret string, timestamp time.Time, payload []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + t + ",v1=" + signature(secret, t, payload)
+}
+
+// Verify checks a delivery's signature header against its payload, rejecting
+// signatures older than tolerance so captured deliveries cannot be replayed
+func Verify(secret, header string, payload []byte, tolerance time.Duration) error {
+	var t string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			t = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	seconds, err := strconv.ParseInt(t, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return fmt.Errorf("%w: malformed header", ErrInvalidSignature)
+	}
+	if tolerance > 0 && time.Since(time.Unix(seconds, 0)) > tolerance {
+		return fmt.Errorf("%w: timestamp is too old", ErrInvalidSignature)
+	}
+
+	expected := signature(secret, t, payload)
+	for _, candidate := range signatures {
+		if hmac.Equal([]byte(candidate), []byte(expected)) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// signature computes the v1 signature of a payload sent at timestamp t
+func signature(secret, t string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
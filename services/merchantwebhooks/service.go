@@ -0,0 +1,585 @@
+package merchantwebhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"apis/payments/services/events"
+	"apis/payments/services/tenancy"
+	"apis/payments/services/tenantcredentials"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Store persists endpoints, deliveries and their attempts. Missing rows
+// return sql.ErrNoRows.
+type Store interface {
+	CreateWebhookEndpoint(ctx context.Context, endpoint *Endpoint) (*Endpoint, error)
+	GetWebhookEndpoint(ctx context.Context, tenantID, id string) (*Endpoint, error)
+	ListWebhookEndpoints(ctx context.Context, tenantID string) ([]*Endpoint, error)
+	UpdateWebhookEndpoint(ctx context.Context, endpoint *Endpoint) (*Endpoint, error)
+	DeleteWebhookEndpoint(ctx context.Context, tenantID, id string) (bool, error)
+	CreateWebhookDelivery(ctx context.Context, delivery *Delivery) (*Delivery, error)
+	GetWebhookDelivery(ctx context.Context, tenantID, id string) (*Delivery, error)
+	ListWebhookDeliveries(ctx context.Context, tenantID, endpointID, status string, limit int) ([]*Delivery, error)
+	ClaimDueWebhookDeliveries(ctx context.Context, leaseUntil time.Time, limit int) ([]*Delivery, error)
+	UpdateWebhookDelivery(ctx context.Context, delivery *Delivery) (*Delivery, error)
+	CreateWebhookDeliveryAttempt(ctx context.Context, attempt *Attempt) (*Attempt, error)
+	ListWebhookDeliveryAttempts(ctx context.Context, deliveryID string) ([]*Attempt, error)
+}
+
+// cipherScope binds encrypted endpoint secrets to their use, as tenant
+// credentials are bound to their provider
+const cipherScope = "webhook_endpoint"
+
+// leaseDuration is how long a claimed delivery is hidden from other workers
+// while it is sent
+const leaseDuration = 2 * time.Minute
+
+// Service fans the events this service emits out to the endpoints tenants
+// register. It is an events.Publisher: each event is queued for the
+// subscribed endpoints of the tenant it was emitted for, then sent signed
+// and retried with exponential backoff until the endpoint accepts it.
+type Service struct {
+	store  Store
+	cipher *tenantcredentials.Cipher
+	config *Config
+	client *http.Client
+	tracer trace.Tracer
+}
+
+// NewService creates a new outgoing webhook service. Without a cipher,
+// endpoint secrets cannot be stored, so endpoints cannot be registered.
+func NewService(store Store, cipher *tenantcredentials.Cipher, config *Config) *Service {
+	return &Service{
+		store:  store,
+		cipher: cipher,
+		config: config,
+		client: newClient(config),
+		tracer: otel.Tracer("payments.merchantwebhooks"),
+	}
+}
+
+// CreateEndpoint registers an endpoint for a tenant with a new signing
+// secret, returned only now
+func (s *Service) CreateEndpoint(ctx context.Context, tenantID string, req CreateEndpointRequest) (*Endpoint, error) {
+	ctx, span := s.tracer.Start(ctx, "CreateEndpoint")
+	defer span.End()
+
+	if s.cipher == nil {
+		return nil, tenantcredentials.ErrEncryptionNotConfigured
+	}
+	if err := s.validateURL(req.URL); err != nil {
+		return nil, err
+	}
+	eventTypes, err := normalizeEventTypes(req.EventTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := s.cipher.Seal(tenantID, cipherScope, []byte(secret))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt webhook secret: %w", err)
+	}
+
+	endpoint, err := s.store.CreateWebhookEndpoint(ctx, &Endpoint{
+		ID:          "we_" + uuid.New().String(),
+		TenantID:    tenantID,
+		URL:         req.URL,
+		Description: req.Description,
+		EventTypes:  eventTypes,
+		Enabled:     true,
+		Ciphertext:  ciphertext,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint.Secret = secret
+	return endpoint, nil
+}
+
+// GetEndpoint returns a tenant's endpoint
+func (s *Service) GetEndpoint(ctx context.Context, tenantID, id string) (*Endpoint, error) {
+	ctx, span := s.tracer.Start(ctx, "GetEndpoint")
+	defer span.End()
+
+	return s.store.GetWebhookEndpoint(ctx, tenantID, id)
+}
+
+// ListEndpoints returns a tenant's endpoints, oldest first
+func (s *Service) ListEndpoints(ctx context.Context, tenantID string) ([]*Endpoint, error) {
+	ctx, span := s.tracer.Start(ctx, "ListEndpoints")
+	defer span.End()
+
+	return s.store.ListWebhookEndpoints(ctx, tenantID)
+}
+
+// UpdateEndpoint changes a tenant's endpoint. Disabled endpoints are sent
+// nothing, and their pending deliveries fail.
+func (s *Service) UpdateEndpoint(ctx context.Context, tenantID, id string, req UpdateEndpointRequest) (*Endpoint, error) {
+	ctx, span := s.tracer.Start(ctx, "UpdateEndpoint")
+	defer span.End()
+
+	endpoint, err := s.store.GetWebhookEndpoint(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.URL != nil {
+		if err := s.validateURL(*req.URL); err != nil {
+			return nil, err
+		}
+		endpoint.URL = *req.URL
+	}
+	if req.Description != nil {
+		endpoint.Description = *req.Description
+	}
+	if req.EventTypes != nil {
+		if endpoint.EventTypes, err = normalizeEventTypes(req.EventTypes); err != nil {
+			return nil, err
+		}
+	}
+	if req.Enabled != nil {
+		endpoint.Enabled = *req.Enabled
+	}
+
+	return s.store.UpdateWebhookEndpoint(ctx, endpoint)
+}
+
+// DeleteEndpoint removes a tenant's endpoint along with its deliveries,
+// reporting whether it existed
+func (s *Service) DeleteEndpoint(ctx context.Context, tenantID, id string) (bool, error) {
+	ctx, span := s.tracer.Start(ctx, "DeleteEndpoint")
+	defer span.End()
+
+	return s.store.DeleteWebhookEndpoint(ctx, tenantID, id)
+}
+
+// Publish queues an event for each enabled endpoint of the tenant the
+// context acts for that subscribes to its type. Deliveries are sent by the
+// worker; publishing the same event again does not queue it twice.
+func (s *Service) Publish(ctx context.Context, event *events.Event) error {
+	ctx, span := s.tracer.Start(ctx, "Publish")
+	defer span.End()
+
+	tenantID := tenancy.ID(ctx)
+	endpoints, err := s.store.ListWebhookEndpoints(ctx, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to list webhook endpoints: %w", err)
+	}
+
+	var payload []byte
+	for _, endpoint := range endpoints {
+		if !endpoint.Enabled || !endpoint.Subscribes(event.Type) {
+			continue
+		}
+		if payload == nil {
+			if payload, err = json.Marshal(event); err != nil {
+				return fmt.Errorf("failed to encode %s event: %w", event.Type, err)
+			}
+		}
+
+		if _, err := s.queue(ctx, endpoint, event, payload); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Requeue queues an event for an endpoint again, such as when replaying
+// archived events. A delivery already made for the event is reset to be
+// sent again with a fresh set of attempts.
+func (s *Service) Requeue(ctx context.Context, endpoint *Endpoint, event *events.Event) error {
+	ctx, span := s.tracer.Start(ctx, "Requeue")
+	defer span.End()
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", event.Type, err)
+	}
+
+	delivery, err := s.queue(ctx, endpoint, event, payload)
+	if err != nil {
+		return err
+	}
+	if delivery.Status == StatusPending && delivery.Attempts == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	delivery.Status = StatusPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = &now
+	delivery.DeliveredAt = nil
+	if _, err := s.store.UpdateWebhookDelivery(ctx, delivery); err != nil {
+		return fmt.Errorf("failed to requeue webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// ListDeliveries returns an endpoint's deliveries, newest first, optionally
+// only those in a status
+func (s *Service) ListDeliveries(ctx context.Context, tenantID, endpointID, status string, limit int) ([]*Delivery, error) {
+	ctx, span := s.tracer.Start(ctx, "ListDeliveries")
+	defer span.End()
+
+	if _, err := s.store.GetWebhookEndpoint(ctx, tenantID, endpointID); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 100
+	}
+	return s.store.ListWebhookDeliveries(ctx, tenantID, endpointID, status, limit)
+}
+
+// GetDelivery returns a tenant's delivery with the log of its attempts
+func (s *Service) GetDelivery(ctx context.Context, tenantID, id string) (*Delivery, error) {
+	ctx, span := s.tracer.Start(ctx, "GetDelivery")
+	defer span.End()
+
+	delivery, err := s.store.GetWebhookDelivery(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.Log, err = s.store.ListWebhookDeliveryAttempts(ctx, delivery.ID); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// Redeliver sends a delivery again now, whatever its status. Success marks
+// it succeeded; failure is logged without changing its status or schedule.
+func (s *Service) Redeliver(ctx context.Context, tenantID, id string) (*Delivery, error) {
+	ctx, span := s.tracer.Start(ctx, "Redeliver")
+	defer span.End()
+
+	delivery, err := s.store.GetWebhookDelivery(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	endpoint, err := s.store.GetWebhookEndpoint(ctx, tenantID, delivery.EndpointID)
+	if err != nil {
+		return nil, err
+	}
+
+	attempt := s.send(ctx, endpoint, delivery)
+	attempt.Manual = true
+	if _, err := s.store.CreateWebhookDeliveryAttempt(ctx, attempt); err != nil {
+		return nil, fmt.Errorf("failed to log webhook delivery attempt: %w", err)
+	}
+
+	delivery.LastStatusCode = attempt.StatusCode
+	delivery.LastError = attempt.Error
+	if attempt.Error == "" {
+		delivery.Status = StatusSucceeded
+		delivery.DeliveredAt = &attempt.AttemptedAt
+		delivery.NextAttemptAt = nil
+	}
+
+	if delivery, err = s.store.UpdateWebhookDelivery(ctx, delivery); err != nil {
+		return nil, fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+	return s.GetDelivery(ctx, tenantID, delivery.ID)
+}
+
+// DeliverDue sends the pending deliveries whose next attempt is due
+func (s *Service) DeliverDue(ctx context.Context) (DeliveryResult, error) {
+	ctx, span := s.tracer.Start(ctx, "DeliverDue")
+	defer span.End()
+
+	var result DeliveryResult
+
+	// Claimed deliveries are leased so concurrent workers skip them
+	deliveries, err := s.store.ClaimDueWebhookDeliveries(ctx, time.Now().Add(leaseDuration), s.config.BatchSize)
+	if err != nil {
+		return result, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+
+	for _, delivery := range deliveries {
+		result.Attempted++
+		updated, err := s.attempt(ctx, delivery)
+		switch {
+		case err != nil:
+			log.Printf("Failed to send webhook delivery %s: %v", delivery.ID, err)
+			result.Retrying++
+		case updated.Status == StatusSucceeded:
+			result.Succeeded++
+		case updated.Status == StatusFailed:
+			result.Failed++
+		default:
+			result.Retrying++
+		}
+	}
+
+	return result, nil
+}
+
+// Start sends due deliveries periodically until stop is called
+func (s *Service) Start() (stop func()) {
+	if !s.config.Enabled {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(s.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				result, err := s.DeliverDue(context.Background())
+				if err != nil {
+					log.Printf("Webhook delivery failed: %v", err)
+				}
+				if result.Failed > 0 {
+					log.Printf("Webhook delivery gave up on %d of %d deliveries", result.Failed, result.Attempted)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// attempt sends a claimed delivery and records the outcome. Failures
+// schedule the next attempt with exponential backoff until attempts run
+// out; deliveries to disabled endpoints fail at once.
+func (s *Service) attempt(ctx context.Context, delivery *Delivery) (*Delivery, error) {
+	endpoint, err := s.store.GetWebhookEndpoint(ctx, delivery.TenantID, delivery.EndpointID)
+	if err != nil {
+		return nil, err
+	}
+
+	var attempt *Attempt
+	if endpoint.Enabled {
+		attempt = s.send(ctx, endpoint, delivery)
+		if _, err := s.store.CreateWebhookDeliveryAttempt(ctx, attempt); err != nil {
+			return nil, fmt.Errorf("failed to log webhook delivery attempt: %w", err)
+		}
+		delivery.Attempts++
+	} else {
+		attempt = &Attempt{Error: "endpoint is disabled", AttemptedAt: time.Now()}
+	}
+
+	delivery.LastStatusCode = attempt.StatusCode
+	delivery.LastError = attempt.Error
+	switch {
+	case attempt.Error == "":
+		delivery.Status = StatusSucceeded
+		delivery.DeliveredAt = &attempt.AttemptedAt
+		delivery.NextAttemptAt = nil
+	case !endpoint.Enabled || delivery.Attempts >= s.config.MaxAttempts:
+		delivery.Status = StatusFailed
+		delivery.NextAttemptAt = nil
+	default:
+		next := attempt.AttemptedAt.Add(s.config.backoff(delivery.Attempts))
+		delivery.NextAttemptAt = &next
+	}
+
+	updated, err := s.store.UpdateWebhookDelivery(ctx, delivery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+	return updated, nil
+}
+
+// queue creates a pending delivery of an event to an endpoint, or returns
+// the delivery already made for it
+func (s *Service) queue(ctx context.Context, endpoint *Endpoint, event *events.Event, payload []byte) (*Delivery, error) {
+	now := time.Now()
+	delivery, err := s.store.CreateWebhookDelivery(ctx, &Delivery{
+		ID:            "wd_" + uuid.New().String(),
+		TenantID:      endpoint.TenantID,
+		EndpointID:    endpoint.ID,
+		EventID:       event.ID,
+		EventType:     event.Type,
+		Payload:       payload,
+		Status:        StatusPending,
+		NextAttemptAt: &now,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to queue webhook delivery: %w", err)
+	}
+	return delivery, nil
+}
+
+// send posts a delivery's payload to its endpoint, signed with the
+// endpoint's secret. Any 2xx response accepts it.
+func (s *Service) send(ctx context.Context, endpoint *Endpoint, delivery *Delivery) *Attempt {
+	attempt := &Attempt{
+		ID:          "wda_" + uuid.New().String(),
+		DeliveryID:  delivery.ID,
+		AttemptedAt: time.Now(),
+	}
+	defer func() {
+		attempt.DurationMS = time.Since(attempt.AttemptedAt).Milliseconds()
+	}()
+
+	secret, err := s.secret(endpoint)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		attempt.Error = fmt.Sprintf("failed to build request: %v", err)
+		return attempt
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "payments-webhooks/1.0")
+	req.Header.Set(SignatureHeader, Sign(secret, attempt.AttemptedAt, delivery.Payload))
+	req.Header.Set(EventIDHeader, delivery.EventID)
+	req.Header.Set(EventTypeHeader, delivery.EventType)
+	req.Header.Set(DeliveryIDHeader, delivery.ID)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	// Only the status is kept, so an endpoint can't be used to read
+	// whatever answers at its URL
+	resp.Body.Close()
+	attempt.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		attempt.Error = fmt.Sprintf("endpoint responded with status %d", resp.StatusCode)
+	}
+	return attempt
+}
+
+// secret decrypts an endpoint's signing secret
+func (s *Service) secret(endpoint *Endpoint) (string, error) {
+	if s.cipher == nil {
+		return "", tenantcredentials.ErrEncryptionNotConfigured
+	}
+	secret, err := s.cipher.Open(endpoint.TenantID, cipherScope, endpoint.Ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt webhook secret: %w", err)
+	}
+	return string(secret), nil
+}
+
+// validateURL checks that an endpoint URL is absolute, uses https, or http
+// when allowed, and doesn't name a private address. Hostnames are checked
+// again when each delivery connects, since they can resolve anywhere.
+func (s *Service) validateURL(value string) error {
+	endpointURL, err := url.Parse(value)
+	if err != nil || endpointURL.Host == "" {
+		return fmt.Errorf("%w: url must be absolute", ErrInvalidEndpoint)
+	}
+	if endpointURL.Scheme != "https" && !(s.config.AllowHTTP && endpointURL.Scheme == "http") {
+		return fmt.Errorf("%w: url must use https", ErrInvalidEndpoint)
+	}
+	if s.config.AllowPrivateNetworks {
+		return nil
+	}
+	host := endpointURL.Hostname()
+	if ip := net.ParseIP(host); (ip != nil && privateIP(ip)) || strings.EqualFold(host, "localhost") {
+		return fmt.Errorf("%w: url must not point at a private, loopback or link-local address", ErrInvalidEndpoint)
+	}
+	return nil
+}
+
+// newClient returns the client deliveries are sent with. Unless private
+// networks are allowed it refuses to connect to private, loopback and
+// link-local addresses, whatever the endpoint's hostname resolves to, and
+// it never follows redirects, so an endpoint can't reach internal services.
+func newClient(config *Config) *http.Client {
+	dialer := &net.Dialer{Timeout: config.Timeout}
+	if !config.AllowPrivateNetworks {
+		dialer.Control = refusePrivateAddresses
+	}
+
+	return &http.Client{
+		Timeout: config.Timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: config.Timeout,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// refusePrivateAddresses is a dialer control refusing connections to
+// private addresses. It runs on the resolved address, so DNS can't be used
+// to get around it.
+func refusePrivateAddresses(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || privateIP(ip) {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, host)
+	}
+	return nil
+}
+
+// privateIP reports whether an address is private, loopback, link-local,
+// unspecified or multicast, which endpoints may not point at
+func privateIP(ip net.IP) bool {
+	return ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified()
+}
+
+// normalizeEventTypes trims event type patterns, subscribing to every event
+// when there are none
+func normalizeEventTypes(eventTypes []string) ([]string, error) {
+	normalized := make([]string, 0, len(eventTypes))
+	for _, eventType := range eventTypes {
+		eventType = strings.TrimSpace(eventType)
+		if eventType == "" {
+			continue
+		}
+		if strings.Contains(strings.TrimSuffix(eventType, "*"), "*") {
+			return nil, fmt.Errorf("%w: only a trailing * is allowed in event type %q", ErrInvalidEndpoint, eventType)
+		}
+		normalized = append(normalized, eventType)
+	}
+	if len(normalized) == 0 {
+		normalized = append(normalized, "*")
+	}
+	return normalized, nil
+}
+
+// newSecret generates an endpoint signing secret
+func newSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
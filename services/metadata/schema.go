@@ -0,0 +1,204 @@
+package metadata
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Resources whose metadata can be validated
+const (
+	ResourceCustomer      = "customer"
+	ResourcePaymentMethod = "payment_method"
+	ResourceCharge        = "charge"
+	ResourceRefund        = "refund"
+)
+
+// Property types. Stripe stores every metadata value as a string, so types
+// describe what the string must parse as.
+const (
+	TypeString  = "string"
+	TypeInteger = "integer"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+)
+
+// ErrInvalidMetadata is returned when metadata does not match the tenant's schema
+var ErrInvalidMetadata = errors.New("metadata does not match schema")
+
+// ErrInvalidSchema is returned when a schema cannot be registered
+var ErrInvalidSchema = errors.New("invalid metadata schema")
+
+// Schema is the JSON Schema subset tenants use to describe allowed metadata:
+//
+//	{
+//	  "type": "object",
+//	  "properties": {"order_id": {"type": "string", "pattern": "^ord_"}},
+//	  "required": ["order_id"],
+//	  "additionalProperties": false
+//	}
+type Schema struct {
+	Type                 string               `json:"type,omitempty"`
+	Properties           map[string]*Property `json:"properties"`
+	Required             []string             `json:"required,omitempty"`
+	AdditionalProperties *bool                `json:"additionalProperties,omitempty"` // false rejects unknown keys
+}
+
+// Property describes one allowed metadata key
+type Property struct {
+	Type      string   `json:"type,omitempty"`
+	Enum      []string `json:"enum,omitempty"`
+	Pattern   string   `json:"pattern,omitempty"`
+	MaxLength int      `json:"maxLength,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// Registration is a schema registered by a tenant for a resource type
+type Registration struct {
+	TenantID  string    `json:"tenant_id"`
+	Resource  string    `json:"resource"`
+	Schema    *Schema   `json:"schema"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Compile checks the schema and prepares it for validation
+func (s *Schema) Compile() error {
+	if s.Type != "" && s.Type != "object" {
+		return fmt.Errorf("%w: type must be \"object\"", ErrInvalidSchema)
+	}
+
+	for key, property := range s.Properties {
+		if property == nil {
+			return fmt.Errorf("%w: property %q has no definition", ErrInvalidSchema, key)
+		}
+
+		switch property.Type {
+		case "", TypeString, TypeInteger, TypeNumber, TypeBoolean:
+		default:
+			return fmt.Errorf("%w: property %q has unsupported type %q", ErrInvalidSchema, key, property.Type)
+		}
+
+		if property.MaxLength < 0 {
+			return fmt.Errorf("%w: property %q has negative maxLength", ErrInvalidSchema, key)
+		}
+
+		if property.Pattern != "" {
+			pattern, err := regexp.Compile(property.Pattern)
+			if err != nil {
+				return fmt.Errorf("%w: property %q has invalid pattern: %v", ErrInvalidSchema, key, err)
+			}
+			property.pattern = pattern
+		}
+	}
+
+	for _, key := range s.Required {
+		if _, ok := s.Properties[key]; !ok {
+			return fmt.Errorf("%w: required key %q is not a property", ErrInvalidSchema, key)
+		}
+	}
+
+	return nil
+}
+
+// Validate checks metadata against a compiled schema and returns every problem found
+func (s *Schema) Validate(metadata map[string]string) error {
+	return s.validate(metadata, false)
+}
+
+// ValidatePartial checks metadata sent in an update, where keys that are not
+// sent keep their stored values, so required keys may be absent
+func (s *Schema) ValidatePartial(metadata map[string]string) error {
+	return s.validate(metadata, true)
+}
+
+// validate checks metadata against a compiled schema
+func (s *Schema) validate(metadata map[string]string, partial bool) error {
+	var problems []string
+
+	if !partial {
+		for _, key := range s.Required {
+			if _, ok := metadata[key]; !ok {
+				problems = append(problems, fmt.Sprintf("%s is required", key))
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		property, ok := s.Properties[key]
+		if !ok {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				problems = append(problems, fmt.Sprintf("%s is not an allowed key", key))
+			}
+			continue
+		}
+
+		if problem := property.check(metadata[key]); problem != "" {
+			problems = append(problems, fmt.Sprintf("%s %s", key, problem))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidMetadata, strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// check returns a description of why the value is invalid, or "" if it is valid
+func (p *Property) check(value string) string {
+	switch p.Type {
+	case TypeInteger:
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return "must be an integer"
+		}
+	case TypeNumber:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return "must be a number"
+		}
+	case TypeBoolean:
+		if value != "true" && value != "false" {
+			return "must be true or false"
+		}
+	}
+
+	if p.MaxLength > 0 && utf8.RuneCountInString(value) > p.MaxLength {
+		return fmt.Sprintf("must be at most %d characters", p.MaxLength)
+	}
+
+	if p.pattern != nil && !p.pattern.MatchString(value) {
+		return fmt.Sprintf("must match %s", p.Pattern)
+	}
+
+	if len(p.Enum) > 0 {
+		for _, allowed := range p.Enum {
+			if value == allowed {
+				return ""
+			}
+		}
+		return fmt.Sprintf("must be one of %s", strings.Join(p.Enum, ", "))
+	}
+
+	return ""
+}
+
+// IsResource reports whether metadata schemas can be registered for a resource type
+func IsResource(resource string) bool {
+	switch resource {
+	case ResourceCustomer, ResourcePaymentMethod, ResourceCharge, ResourceRefund:
+		return true
+	}
+	return false
+}
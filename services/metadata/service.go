@@ -0,0 +1,130 @@
+package metadata
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Store persists tenant metadata schemas
+type Store interface {
+	GetMetadataSchema(ctx context.Context, tenantID, resource string) (*Registration, error)
+	ListMetadataSchemas(ctx context.Context, tenantID string) ([]*Registration, error)
+	UpsertMetadataSchema(ctx context.Context, registration *Registration) (*Registration, error)
+	DeleteMetadataSchema(ctx context.Context, tenantID, resource string) (bool, error)
+}
+
+// Service registers tenant metadata schemas and validates metadata against them
+type Service struct {
+	store  Store
+	tracer trace.Tracer
+}
+
+// NewService creates a new metadata schema service
+func NewService(store Store) *Service {
+	return &Service{
+		store:  store,
+		tracer: otel.Tracer("payments.metadata"),
+	}
+}
+
+// GetSchema returns the schema a tenant registered for a resource type
+func (s *Service) GetSchema(ctx context.Context, tenantID, resource string) (*Registration, error) {
+	ctx, span := s.tracer.Start(ctx, "GetSchema")
+	defer span.End()
+
+	registration, err := s.store.GetMetadataSchema(ctx, tenantID, resource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metadata schema: %w", err)
+	}
+
+	return registration, nil
+}
+
+// ListSchemas returns every schema a tenant has registered
+func (s *Service) ListSchemas(ctx context.Context, tenantID string) ([]*Registration, error) {
+	ctx, span := s.tracer.Start(ctx, "ListSchemas")
+	defer span.End()
+
+	return s.store.ListMetadataSchemas(ctx, tenantID)
+}
+
+// RegisterSchema stores a tenant's schema for a resource type, replacing any existing one
+func (s *Service) RegisterSchema(ctx context.Context, tenantID, resource string, schema *Schema) (*Registration, error) {
+	ctx, span := s.tracer.Start(ctx, "RegisterSchema")
+	defer span.End()
+
+	if !IsResource(resource) {
+		return nil, fmt.Errorf("%w: unknown resource %q", ErrInvalidSchema, resource)
+	}
+
+	if schema == nil {
+		return nil, fmt.Errorf("%w: schema cannot be empty", ErrInvalidSchema)
+	}
+
+	if err := schema.Compile(); err != nil {
+		return nil, err
+	}
+
+	return s.store.UpsertMetadataSchema(ctx, &Registration{
+		TenantID: tenantID,
+		Resource: resource,
+		Schema:   schema,
+	})
+}
+
+// DeleteSchema removes a tenant's schema so the resource's metadata is no longer validated
+func (s *Service) DeleteSchema(ctx context.Context, tenantID, resource string) (bool, error) {
+	ctx, span := s.tracer.Start(ctx, "DeleteSchema")
+	defer span.End()
+
+	return s.store.DeleteMetadataSchema(ctx, tenantID, resource)
+}
+
+// Validate checks metadata for a new resource against the tenant's schema for
+// the resource type. Metadata is accepted as-is when no schema is registered.
+func (s *Service) Validate(ctx context.Context, tenantID, resource string, metadata map[string]string) error {
+	ctx, span := s.tracer.Start(ctx, "Validate")
+	defer span.End()
+
+	schema, err := s.schema(ctx, tenantID, resource)
+	if err != nil || schema == nil {
+		return err
+	}
+
+	return schema.Validate(metadata)
+}
+
+// ValidateUpdate checks metadata sent to update an existing resource
+func (s *Service) ValidateUpdate(ctx context.Context, tenantID, resource string, metadata map[string]string) error {
+	ctx, span := s.tracer.Start(ctx, "ValidateUpdate")
+	defer span.End()
+
+	schema, err := s.schema(ctx, tenantID, resource)
+	if err != nil || schema == nil {
+		return err
+	}
+
+	return schema.ValidatePartial(metadata)
+}
+
+// schema returns the tenant's compiled schema for a resource type, or nil if none is registered
+func (s *Service) schema(ctx context.Context, tenantID, resource string) (*Schema, error) {
+	registration, err := s.store.GetMetadataSchema(ctx, tenantID, resource)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metadata schema: %w", err)
+	}
+
+	if err := registration.Schema.Compile(); err != nil {
+		return nil, err
+	}
+
+	return registration.Schema, nil
+}
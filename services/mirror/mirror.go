@@ -0,0 +1,29 @@
+package mirror
+
+import (
+	"context"
+	"time"
+
+	"apis/payments/services/stripe"
+)
+
+// SubscriptionFilter narrows a mirrored subscription query
+type SubscriptionFilter struct {
+	CustomerID string
+	Status     string
+	Limit      int
+}
+
+// Store persists the local copy of provider objects. Upserts are ignored
+// when a copy synced after syncedAt is already stored.
+type Store interface {
+	UpsertMirroredCustomer(ctx context.Context, customer *stripe.Customer, syncedAt time.Time) error
+	DeleteMirroredCustomer(ctx context.Context, customerID string) error
+	UpsertMirroredPaymentMethod(ctx context.Context, paymentMethod *stripe.PaymentMethod, syncedAt time.Time) error
+	DeleteMirroredPaymentMethod(ctx context.Context, paymentMethodID string, syncedAt time.Time) error
+	UpsertMirroredCharge(ctx context.Context, charge *stripe.Charge, syncedAt time.Time) error
+	MarkMirroredChargeDisputed(ctx context.Context, chargeID string) error
+	UpsertMirroredRefund(ctx context.Context, refund *stripe.Refund, syncedAt time.Time) error
+	UpsertSubscription(ctx context.Context, subscription *stripe.Subscription, syncedAt time.Time) error
+	UpsertSubscriptionPlan(ctx context.Context, plan *stripe.SubscriptionPlan, syncedAt time.Time) error
+}
@@ -0,0 +1,92 @@
+package mirror
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"apis/payments/services/stripe"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Service keeps the local database a complete copy of provider state. The
+// provider services report every mutation they make, and provider events
+// cover changes made elsewhere, e.g. in the dashboard.
+type Service struct {
+	store  Store
+	tracer trace.Tracer
+}
+
+// NewService creates a new mirror service
+func NewService(store Store) *Service {
+	return &Service{
+		store:  store,
+		tracer: otel.Tracer("payments.mirror"),
+	}
+}
+
+// SaveCustomer stores a customer after a mutation
+func (s *Service) SaveCustomer(ctx context.Context, customer *stripe.Customer) {
+	ctx, span := s.tracer.Start(ctx, "SaveCustomer")
+	defer span.End()
+
+	s.logFailure("customer", customer.ID, s.store.UpsertMirroredCustomer(ctx, customer, time.Now()))
+}
+
+// RemoveCustomer removes a deleted customer and its payment methods
+func (s *Service) RemoveCustomer(ctx context.Context, customerID string) {
+	ctx, span := s.tracer.Start(ctx, "RemoveCustomer")
+	defer span.End()
+
+	s.logFailure("customer", customerID, s.store.DeleteMirroredCustomer(ctx, customerID))
+}
+
+// SavePaymentMethod stores a payment method after a mutation
+func (s *Service) SavePaymentMethod(ctx context.Context, paymentMethod *stripe.PaymentMethod) {
+	ctx, span := s.tracer.Start(ctx, "SavePaymentMethod")
+	defer span.End()
+
+	s.logFailure("payment method", paymentMethod.ID, s.store.UpsertMirroredPaymentMethod(ctx, paymentMethod, time.Now()))
+}
+
+// RemovePaymentMethod removes a detached payment method
+func (s *Service) RemovePaymentMethod(ctx context.Context, paymentMethodID string) {
+	ctx, span := s.tracer.Start(ctx, "RemovePaymentMethod")
+	defer span.End()
+
+	s.logFailure("payment method", paymentMethodID, s.store.DeleteMirroredPaymentMethod(ctx, paymentMethodID, time.Now()))
+}
+
+// SaveCharge stores a charge after a mutation
+func (s *Service) SaveCharge(ctx context.Context, charge *stripe.Charge) {
+	ctx, span := s.tracer.Start(ctx, "SaveCharge")
+	defer span.End()
+
+	s.logFailure("charge", charge.ID, s.store.UpsertMirroredCharge(ctx, charge, time.Now()))
+}
+
+// SaveRefund stores a refund after a mutation
+func (s *Service) SaveRefund(ctx context.Context, refund *stripe.Refund) {
+	ctx, span := s.tracer.Start(ctx, "SaveRefund")
+	defer span.End()
+
+	s.logFailure("refund", refund.ID, s.store.UpsertMirroredRefund(ctx, refund, time.Now()))
+}
+
+// SaveSubscription stores a subscription after a mutation
+func (s *Service) SaveSubscription(ctx context.Context, subscription *stripe.Subscription) {
+	ctx, span := s.tracer.Start(ctx, "SaveSubscription")
+	defer span.End()
+
+	s.logFailure("subscription", subscription.ID, s.store.UpsertSubscription(ctx, subscription, time.Now()))
+}
+
+// logFailure logs a failed write. The mutation already succeeded at the
+// provider, so it is not failed; the provider event for it repairs the copy.
+func (s *Service) logFailure(kind, id string, err error) {
+	if err != nil {
+		log.Printf("Failed to mirror %s %s: %v", kind, id, err)
+	}
+}
@@ -0,0 +1,172 @@
+package mirror
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"apis/payments/services/stripe"
+
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// Events that carry the current state of an object
+var (
+	customerEvents = []stripego.EventType{
+		stripego.EventTypeCustomerCreated,
+		stripego.EventTypeCustomerUpdated,
+	}
+	paymentMethodEvents = []stripego.EventType{
+		stripego.EventTypePaymentMethodAttached,
+		stripego.EventTypePaymentMethodUpdated,
+		stripego.EventTypePaymentMethodAutomaticallyUpdated,
+	}
+	chargeEvents = []stripego.EventType{
+		stripego.EventTypeChargeSucceeded,
+		stripego.EventTypeChargeFailed,
+		stripego.EventTypeChargePending,
+		stripego.EventTypeChargeCaptured,
+		stripego.EventTypeChargeExpired,
+		stripego.EventTypeChargeUpdated,
+		stripego.EventTypeChargeRefunded,
+	}
+	refundEvents = []stripego.EventType{
+		stripego.EventTypeRefundCreated,
+		stripego.EventTypeRefundUpdated,
+		stripego.EventTypeChargeRefundUpdated,
+	}
+	subscriptionEvents = []stripego.EventType{
+		stripego.EventTypeCustomerSubscriptionCreated,
+		stripego.EventTypeCustomerSubscriptionUpdated,
+		stripego.EventTypeCustomerSubscriptionDeleted,
+		stripego.EventTypeCustomerSubscriptionPaused,
+		stripego.EventTypeCustomerSubscriptionResumed,
+	}
+	planEvents = []stripego.EventType{
+		stripego.EventTypePriceCreated,
+		stripego.EventTypePriceUpdated,
+		stripego.EventTypePriceDeleted,
+	}
+)
+
+// RegisterWebhookHandlers keeps the local copy up to date with changes made
+// outside this service. Disputes are kept by the disputes service.
+func (s *Service) RegisterWebhookHandlers(webhooks *stripe.WebhookService) {
+	for _, eventType := range customerEvents {
+		webhooks.On(eventType, func(ctx context.Context, event stripego.Event) error {
+			var customer stripego.Customer
+			if err := json.Unmarshal(event.Data.Raw, &customer); err != nil {
+				return fmt.Errorf("failed to parse customer: %w", err)
+			}
+
+			return s.store.UpsertMirroredCustomer(ctx, stripe.ConvertCustomer(&customer), syncedAt(event))
+		})
+	}
+
+	webhooks.On(stripego.EventTypeCustomerDeleted, func(ctx context.Context, event stripego.Event) error {
+		var customer stripego.Customer
+		if err := json.Unmarshal(event.Data.Raw, &customer); err != nil {
+			return fmt.Errorf("failed to parse customer: %w", err)
+		}
+
+		return s.store.DeleteMirroredCustomer(ctx, customer.ID)
+	})
+
+	for _, eventType := range paymentMethodEvents {
+		webhooks.On(eventType, func(ctx context.Context, event stripego.Event) error {
+			var paymentMethod stripego.PaymentMethod
+			if err := json.Unmarshal(event.Data.Raw, &paymentMethod); err != nil {
+				return fmt.Errorf("failed to parse payment method: %w", err)
+			}
+
+			// Only payment methods attached to a customer are kept
+			if paymentMethod.Customer == nil {
+				return nil
+			}
+
+			return s.store.UpsertMirroredPaymentMethod(ctx, stripe.ConvertPaymentMethod(&paymentMethod), syncedAt(event))
+		})
+	}
+
+	webhooks.On(stripego.EventTypePaymentMethodDetached, func(ctx context.Context, event stripego.Event) error {
+		var paymentMethod stripego.PaymentMethod
+		if err := json.Unmarshal(event.Data.Raw, &paymentMethod); err != nil {
+			return fmt.Errorf("failed to parse payment method: %w", err)
+		}
+
+		return s.store.DeleteMirroredPaymentMethod(ctx, paymentMethod.ID, syncedAt(event))
+	})
+
+	for _, eventType := range chargeEvents {
+		webhooks.On(eventType, func(ctx context.Context, event stripego.Event) error {
+			var charge stripego.Charge
+			if err := json.Unmarshal(event.Data.Raw, &charge); err != nil {
+				return fmt.Errorf("failed to parse charge: %w", err)
+			}
+
+			return s.store.UpsertMirroredCharge(ctx, stripe.ConvertCharge(&charge), syncedAt(event))
+		})
+	}
+
+	// Charges are not updated when a dispute opens, so the flag is set here
+	webhooks.On(stripego.EventTypeChargeDisputeCreated, func(ctx context.Context, event stripego.Event) error {
+		var dispute stripego.Dispute
+		if err := json.Unmarshal(event.Data.Raw, &dispute); err != nil {
+			return fmt.Errorf("failed to parse dispute: %w", err)
+		}
+		if dispute.Charge == nil {
+			return nil
+		}
+
+		return s.store.MarkMirroredChargeDisputed(ctx, dispute.Charge.ID)
+	})
+
+	for _, eventType := range refundEvents {
+		webhooks.On(eventType, func(ctx context.Context, event stripego.Event) error {
+			var refund stripego.Refund
+			if err := json.Unmarshal(event.Data.Raw, &refund); err != nil {
+				return fmt.Errorf("failed to parse refund: %w", err)
+			}
+
+			return s.store.UpsertMirroredRefund(ctx, stripe.ConvertRefund(&refund), syncedAt(event))
+		})
+	}
+
+	for _, eventType := range subscriptionEvents {
+		webhooks.On(eventType, func(ctx context.Context, event stripego.Event) error {
+			var subscription stripego.Subscription
+			if err := json.Unmarshal(event.Data.Raw, &subscription); err != nil {
+				return fmt.Errorf("failed to parse subscription: %w", err)
+			}
+
+			return s.store.UpsertSubscription(ctx, stripe.ConvertSubscription(&subscription), syncedAt(event))
+		})
+	}
+
+	for _, eventType := range planEvents {
+		webhooks.On(eventType, func(ctx context.Context, event stripego.Event) error {
+			var price stripego.Price
+			if err := json.Unmarshal(event.Data.Raw, &price); err != nil {
+				return fmt.Errorf("failed to parse price: %w", err)
+			}
+
+			// Only recurring prices are subscription plans
+			if price.Recurring == nil {
+				return nil
+			}
+
+			plan := stripe.ConvertPlan(&price)
+			if event.Type == stripego.EventTypePriceDeleted {
+				plan.Active = false
+			}
+
+			return s.store.UpsertSubscriptionPlan(ctx, plan, syncedAt(event))
+		})
+	}
+}
+
+// syncedAt is the time the provider state in an event was current
+func syncedAt(event stripego.Event) time.Time {
+	return time.Unix(event.Created, 0).UTC()
+}
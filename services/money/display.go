@@ -0,0 +1,106 @@
+package money
+
+import (
+	"strings"
+)
+
+// Display is presentation metadata for an amount, derived from its minor
+// units and currency so clients never redo minor-unit math themselves
+type Display struct {
+	Decimal   string `json:"decimal"`   // e.g. "1234.56", "1000" for JPY, "1.234" for KWD
+	Exponent  int    `json:"exponent"`  // Decimal places in the currency's minor unit
+	Symbol    string `json:"symbol"`    // e.g. "$", "¥", "KD"
+	Formatted string `json:"formatted"` // e.g. "$1,234.56", "1.234,56 €", "¥1,000"
+}
+
+// style is how a currency's home country writes amounts
+type style struct {
+	symbol  string
+	group   string // Thousands separator
+	point   string // Decimal separator
+	suffix  bool   // Symbol follows the number
+	spacing bool   // Space between symbol and number
+}
+
+// styles holds formatting conventions for common currencies. Currencies not
+// listed are written with their upper-case code after the number.
+var styles = map[string]style{
+	"aed": {symbol: "AED", group: ",", point: ".", spacing: true},
+	"aud": {symbol: "A$", group: ",", point: "."},
+	"bhd": {symbol: "BD", group: ",", point: ".", spacing: true},
+	"brl": {symbol: "R$", group: ".", point: ",", spacing: true},
+	"cad": {symbol: "CA$", group: ",", point: "."},
+	"chf": {symbol: "CHF", group: "'", point: ".", spacing: true},
+	"clp": {symbol: "$", group: ".", point: ","},
+	"cny": {symbol: "CN¥", group: ",", point: "."},
+	"czk": {symbol: "Kč", group: " ", point: ",", suffix: true, spacing: true},
+	"dkk": {symbol: "kr.", group: ".", point: ",", suffix: true, spacing: true},
+	"eur": {symbol: "€", group: ".", point: ",", suffix: true, spacing: true},
+	"gbp": {symbol: "£", group: ",", point: "."},
+	"hkd": {symbol: "HK$", group: ",", point: "."},
+	"inr": {symbol: "₹", group: ",", point: "."},
+	"jod": {symbol: "JD", group: ",", point: ".", spacing: true},
+	"jpy": {symbol: "¥", group: ",", point: "."},
+	"krw": {symbol: "₩", group: ",", point: "."},
+	"kwd": {symbol: "KD", group: ",", point: ".", spacing: true},
+	"mxn": {symbol: "MX$", group: ",", point: "."},
+	"nok": {symbol: "kr", group: " ", point: ",", suffix: true, spacing: true},
+	"nzd": {symbol: "NZ$", group: ",", point: "."},
+	"omr": {symbol: "OMR", group: ",", point: ".", spacing: true},
+	"pln": {symbol: "zł", group: " ", point: ",", suffix: true, spacing: true},
+	"sek": {symbol: "kr", group: " ", point: ",", suffix: true, spacing: true},
+	"sgd": {symbol: "S$", group: ",", point: "."},
+	"usd": {symbol: "$", group: ",", point: "."},
+	"vnd": {symbol: "₫", group: ".", point: ",", suffix: true, spacing: true},
+	"zar": {symbol: "R", group: " ", point: ",", spacing: true},
+}
+
+// Describe derives the display metadata for an amount in minor units
+func Describe(minor int64, currency string) Display {
+	currency = strings.ToLower(currency)
+	decimal := FormatDecimal(minor, currency)
+
+	s, ok := styles[currency]
+	if !ok {
+		s = style{symbol: strings.ToUpper(currency), group: ",", point: ".", suffix: true, spacing: true}
+	}
+
+	return Display{
+		Decimal:   decimal,
+		Exponent:  Exponent(currency),
+		Symbol:    s.symbol,
+		Formatted: s.format(decimal),
+	}
+}
+
+// format writes a decimal string with the style's separators and symbol
+func (s style) format(decimal string) string {
+	sign := ""
+	if strings.HasPrefix(decimal, "-") {
+		sign, decimal = "-", decimal[1:]
+	}
+
+	whole, fraction, hasPoint := strings.Cut(decimal, ".")
+
+	var grouped strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			grouped.WriteString(s.group)
+		}
+		grouped.WriteRune(digit)
+	}
+	number := grouped.String()
+	if hasPoint {
+		number += s.point + fraction
+	}
+
+	space := ""
+	if s.spacing {
+		space = " "
+	}
+
+	if s.suffix {
+		return sign + number + space + s.symbol
+	}
+	return sign + s.symbol + space + number
+}
@@ -0,0 +1,246 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// ErrInvalidDecimal is returned when a decimal amount is malformed or cannot
+// be represented exactly in the currency's minor units
+var ErrInvalidDecimal = errors.New("invalid decimal amount")
+
+// ErrAmountMismatch is returned when both minor units and a decimal amount are
+// given and they disagree
+var ErrAmountMismatch = errors.New("amount and amount_decimal do not match")
+
+// ErrInvalidCurrency is returned when a currency is not a three-letter ISO
+// 4217 code
+var ErrInvalidCurrency = errors.New("currency must be a three-letter ISO 4217 code")
+
+// ErrBelowMinimum is returned when an amount is below the smallest amount
+// that can be charged in its currency
+var ErrBelowMinimum = errors.New("amount is below the minimum chargeable amount")
+
+// zeroDecimalCurrencies have no minor unit
+var zeroDecimalCurrencies = map[string]bool{
+	"bif": true, "clp": true, "djf": true, "gnf": true, "jpy": true, "kmf": true,
+	"krw": true, "mga": true, "pyg": true, "rwf": true, "ugx": true, "vnd": true,
+	"vuv": true, "xaf": true, "xof": true, "xpf": true,
+}
+
+// threeDecimalCurrencies have a minor unit of 1/1000
+var threeDecimalCurrencies = map[string]bool{
+	"bhd": true, "jod": true, "kwd": true, "omr": true, "tnd": true,
+}
+
+// minimumAmounts are the smallest chargeable amounts in minor units, as set
+// by card networks and Stripe. They are defined per currency because minor
+// units differ: 50 is $0.50 in USD but ¥50 in JPY. Currencies not listed
+// only need a positive amount.
+var minimumAmounts = map[string]int64{
+	"aed": 200, "aud": 50, "bgn": 100, "brl": 50, "cad": 50, "chf": 50,
+	"czk": 1500, "dkk": 250, "eur": 50, "gbp": 30, "hkd": 400, "huf": 17500,
+	"inr": 50, "jpy": 50, "mxn": 1000, "myr": 200, "nok": 300, "nzd": 50,
+	"pln": 200, "ron": 200, "sek": 300, "sgd": 50, "thb": 1000, "usd": 50,
+}
+
+// Money is an amount in a currency's minor units
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// New creates an amount of money in minor units
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToLower(currency)}
+}
+
+// Decimal returns the amount as a decimal string such as "10.00"
+func (m Money) Decimal() string {
+	return FormatDecimal(m.Amount, m.Currency)
+}
+
+// Display returns the display metadata for the amount
+func (m Money) Display() Display {
+	return Describe(m.Amount, m.Currency)
+}
+
+// String returns the amount with its currency code, e.g. "10.00 USD"
+func (m Money) String() string {
+	return m.Decimal() + " " + strings.ToUpper(m.Currency)
+}
+
+// Convert converts the amount to another currency at rate units of the
+// target currency per unit of this one. The minor units of both currencies
+// are accounted for, so 1000 JPY at 0.0067 is 670 USD cents. The result is
+// rounded half away from zero to the target's minor unit.
+func (m Money) Convert(currency string, rate *big.Rat) Money {
+	converted := new(big.Rat).Mul(new(big.Rat).SetInt64(m.Amount), rate)
+	if shift := Exponent(currency) - Exponent(m.Currency); shift != 0 {
+		scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(shift))), nil))
+		if shift > 0 {
+			converted.Mul(converted, scale)
+		} else {
+			converted.Quo(converted, scale)
+		}
+	}
+
+	// Round half away from zero: add or subtract one half, then truncate
+	half := big.NewRat(1, 2)
+	if converted.Sign() < 0 {
+		converted.Sub(converted, half)
+	} else {
+		converted.Add(converted, half)
+	}
+	rounded := new(big.Int).Quo(converted.Num(), converted.Denom())
+
+	return New(rounded.Int64(), currency)
+}
+
+// ValidCurrency reports whether a currency is a three-letter code
+func ValidCurrency(currency string) bool {
+	if len(currency) != 3 {
+		return false
+	}
+	for _, r := range strings.ToLower(currency) {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// MinimumAmount returns the smallest chargeable amount in a currency's minor
+// units
+func MinimumAmount(currency string) int64 {
+	if minimum, ok := minimumAmounts[strings.ToLower(currency)]; ok {
+		return minimum
+	}
+	return 1
+}
+
+// ValidateAmount checks that an amount in minor units can be charged in its
+// currency
+func ValidateAmount(amount int64, currency string) error {
+	if !ValidCurrency(currency) {
+		return ErrInvalidCurrency
+	}
+	if amount <= 0 {
+		return fmt.Errorf("amount must be positive")
+	}
+	if minimum := MinimumAmount(currency); amount < minimum {
+		return fmt.Errorf("%w: %s is less than %s", ErrBelowMinimum, Describe(amount, currency).Formatted, Describe(minimum, currency).Formatted)
+	}
+	return nil
+}
+
+// Exponent returns the number of decimal places in a currency's minor unit
+func Exponent(currency string) int {
+	currency = strings.ToLower(currency)
+	switch {
+	case zeroDecimalCurrencies[currency]:
+		return 0
+	case threeDecimalCurrencies[currency]:
+		return 3
+	default:
+		return 2
+	}
+}
+
+// ParseDecimal converts a decimal string such as "10.00" to minor units.
+// Parsing is exact: digits beyond the currency's precision are only accepted
+// when they are zeros, so nothing is ever rounded.
+func ParseDecimal(amount, currency string) (int64, error) {
+	if currency == "" {
+		return 0, fmt.Errorf("%w: currency is required", ErrInvalidDecimal)
+	}
+
+	whole, fraction, hasPoint := strings.Cut(amount, ".")
+	if whole == "" || (hasPoint && fraction == "") || !isDigits(whole) || !isDigits(fraction) {
+		return 0, fmt.Errorf("%w: %q is not a plain decimal number", ErrInvalidDecimal, amount)
+	}
+
+	exponent := Exponent(currency)
+	if len(fraction) > exponent {
+		if strings.Trim(fraction[exponent:], "0") != "" {
+			return 0, fmt.Errorf("%w: %s supports at most %d decimal places", ErrInvalidDecimal, strings.ToUpper(currency), exponent)
+		}
+		fraction = fraction[:exponent]
+	}
+	fraction += strings.Repeat("0", exponent-len(fraction))
+
+	var minor int64
+	for _, digit := range whole + fraction {
+		d := int64(digit - '0')
+		if minor > (math.MaxInt64-d)/10 {
+			return 0, fmt.Errorf("%w: %q is too large", ErrInvalidDecimal, amount)
+		}
+		minor = minor*10 + d
+	}
+
+	return minor, nil
+}
+
+// FormatDecimal converts minor units to a decimal string such as "10.00"
+func FormatDecimal(minor int64, currency string) string {
+	exponent := Exponent(currency)
+
+	sign := ""
+	if minor < 0 {
+		sign = "-"
+	}
+
+	digits := fmt.Sprintf("%d", minor)
+	digits = strings.TrimPrefix(digits, "-")
+	if exponent == 0 {
+		return sign + digits
+	}
+
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+
+	point := len(digits) - exponent
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// ResolveAmount returns the amount in minor units from a request that may
+// carry minor units, a decimal amount, or both. When both are set they must
+// agree exactly.
+func ResolveAmount(minor int64, decimal, currency string) (int64, error) {
+	if decimal == "" {
+		return minor, nil
+	}
+
+	parsed, err := ParseDecimal(decimal, currency)
+	if err != nil {
+		return 0, err
+	}
+
+	if minor != 0 && minor != parsed {
+		return 0, fmt.Errorf("%w: %d minor units is %s %s", ErrAmountMismatch, minor, FormatDecimal(minor, currency), strings.ToUpper(currency))
+	}
+
+	return parsed, nil
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// isDigits reports whether s contains only ASCII digits
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,215 @@
+package offboarding
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// MinRecipientKeyBits is the smallest RSA key archives are encrypted to
+const MinRecipientKeyBits = 2048
+
+// Sealed archives start with archiveMagic and a format version, followed by
+// the length of the wrapped key as a big-endian uint16, the AES-256 key
+// wrapped with RSA-OAEP (SHA-256, label archiveLabel), the GCM nonce and the
+// encrypted tar.gz. Everything before the nonce is authenticated as
+// additional data.
+const (
+	archiveMagic   = "PMXA"
+	archiveVersion = 1
+)
+
+// archiveLabel binds wrapped keys to offboarding archives
+var archiveLabel = []byte("offboarding-archive")
+
+// ErrInvalidArchive is returned when opening data that is not a sealed archive
+var ErrInvalidArchive = errors.New("not a sealed offboarding archive")
+
+// ParseRecipientKey parses the PEM-encoded RSA public key an archive is
+// encrypted to, as a PKIX public key or a PKCS #1 RSA public key
+func ParseRecipientKey(encoded string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(encoded))
+	if block == nil {
+		return nil, ErrInvalidRecipientKey
+	}
+
+	var key *rsa.PublicKey
+	switch block.Type {
+	case "PUBLIC KEY":
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, ErrInvalidRecipientKey
+		}
+		key, _ = parsed.(*rsa.PublicKey)
+	case "RSA PUBLIC KEY":
+		key, _ = x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+	if key == nil || key.N.BitLen() < MinRecipientKeyBits {
+		return nil, ErrInvalidRecipientKey
+	}
+
+	return key, nil
+}
+
+// Seal encrypts an archive to the recipient's key with a fresh AES-256 key
+func Seal(archive []byte, recipient *rsa.PublicKey) ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate archive key: %w", err)
+	}
+
+	wrapped, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, recipient, key, archiveLabel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap archive key: %w", err)
+	}
+
+	header := []byte(archiveMagic)
+	header = append(header, archiveVersion)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(wrapped)))
+	header = append(header, wrapped...)
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := append(header, nonce...)
+	return gcm.Seal(sealed, nonce, archive, header), nil
+}
+
+// Open decrypts a sealed archive with the recipient's private key
+func Open(sealed []byte, recipient *rsa.PrivateKey) ([]byte, error) {
+	prefix := len(archiveMagic) + 3
+	if len(sealed) < prefix || string(sealed[:len(archiveMagic)]) != archiveMagic || sealed[len(archiveMagic)] != archiveVersion {
+		return nil, ErrInvalidArchive
+	}
+
+	headerSize := prefix + int(binary.BigEndian.Uint16(sealed[len(archiveMagic)+1:]))
+	if len(sealed) < headerSize {
+		return nil, ErrInvalidArchive
+	}
+	header := sealed[:headerSize]
+
+	key, err := rsa.DecryptOAEP(sha256.New(), nil, recipient, header[prefix:], archiveLabel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap archive key: %w", err)
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < headerSize+gcm.NonceSize() {
+		return nil, ErrInvalidArchive
+	}
+	nonce := sealed[headerSize : headerSize+gcm.NonceSize()]
+
+	archive, err := gcm.Open(nil, nonce, sealed[headerSize+gcm.NonceSize():], header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt archive: %w", err)
+	}
+	return archive, nil
+}
+
+// newGCM creates an AES-GCM cipher with a 256-bit key
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// archiveWriter writes JSON Lines files into a tar.gz archive, recording
+// each in the manifest
+type archiveWriter struct {
+	buffer   bytes.Buffer
+	gzip     *gzip.Writer
+	tar      *tar.Writer
+	manifest *Manifest
+}
+
+// newArchiveWriter starts an archive described by manifest
+func newArchiveWriter(manifest *Manifest) *archiveWriter {
+	w := &archiveWriter{manifest: manifest}
+	w.gzip = gzip.NewWriter(&w.buffer)
+	w.tar = tar.NewWriter(w.gzip)
+	return w
+}
+
+// WriteRecords writes records as a JSON Lines file. Every file is listed in
+// the manifest, empty ones too, so recipients can tell none were dropped.
+func (w *archiveWriter) WriteRecords(name string, records []any) error {
+	var content bytes.Buffer
+	encoder := json.NewEncoder(&content)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return fmt.Errorf("failed to encode %s: %w", name, err)
+		}
+	}
+
+	if err := w.write(name, content.Bytes()); err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(content.Bytes())
+	w.manifest.Files = append(w.manifest.Files, ManifestFile{
+		Name:    name,
+		Records: len(records),
+		SHA256:  hex.EncodeToString(sum[:]),
+	})
+	return nil
+}
+
+// Close writes the manifest and returns the archive
+func (w *archiveWriter) Close() ([]byte, error) {
+	manifest, err := json.MarshalIndent(w.manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := w.write("manifest.json", manifest); err != nil {
+		return nil, err
+	}
+
+	if err := w.tar.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish archive: %w", err)
+	}
+	if err := w.gzip.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress archive: %w", err)
+	}
+	return w.buffer.Bytes(), nil
+}
+
+// write adds a file to the archive
+func (w *archiveWriter) write(name string, content []byte) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0o600,
+		Size:    int64(len(content)),
+		ModTime: w.manifest.CreatedAt.Truncate(time.Second),
+	}
+	if err := w.tar.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to add %s to archive: %w", name, err)
+	}
+	if _, err := w.tar.Write(content); err != nil {
+		return fmt.Errorf("failed to add %s to archive: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package offboarding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// LogMigrationHook writes PAN migration requests to the service log, for an
+// operator to file with the provider
+type LogMigrationHook struct{}
+
+// RequestMigration logs the migration
+func (LogMigrationHook) RequestMigration(ctx context.Context, migration *Migration) (string, error) {
+	log.Printf("PAN migration requested for export %s: %d payment methods of %d customers of tenant %s at %s to %s",
+		migration.ExportID, len(migration.PaymentMethodIDs), len(migration.CustomerIDs), migration.TenantID, migration.Provider, migration.Request.Destination)
+	return "", nil
+}
+
+// WebhookMigrationHook posts PAN migration requests as JSON to an endpoint,
+// such as a ticketing system that files them with the provider. The
+// reference field of a JSON response, when there is one, is kept as the
+// migration's reference.
+type WebhookMigrationHook struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookMigrationHook creates a hook posting to the given URL
+func NewWebhookMigrationHook(url string) *WebhookMigrationHook {
+	return &WebhookMigrationHook{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// RequestMigration posts the migration to the configured URL
+func (h *WebhookMigrationHook) RequestMigration(ctx context.Context, migration *Migration) (string, error) {
+	body, err := json.Marshal(migration)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal migration: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to build migration request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send migration request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("migration hook returned status %d", resp.StatusCode)
+	}
+
+	var response struct {
+		Reference string `json:"reference"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&response)
+	return response.Reference, nil
+}
@@ -0,0 +1,158 @@
+package offboarding
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"time"
+
+	"apis/payments/services/mirror"
+	"apis/payments/services/stripe"
+	"apis/payments/services/vault"
+)
+
+// Export statuses
+const (
+	StatusPending   = "pending"   // Waiting for a worker to build the archive
+	StatusRunning   = "running"   // A worker is building the archive
+	StatusCompleted = "completed" // The archive can be downloaded
+	StatusFailed    = "failed"
+)
+
+// PAN migration statuses
+const (
+	MigrationRequested = "requested" // The provider was asked to transfer card numbers
+	MigrationFailed    = "failed"    // The request could not be sent and can be retried
+)
+
+// ErrInvalidRecipientKey is returned when the key an export is encrypted to
+// is not a PEM-encoded RSA public key of at least MinRecipientKeyBits bits
+var ErrInvalidRecipientKey = errors.New("recipient_public_key must be a PEM-encoded RSA public key of at least 2048 bits")
+
+// ErrNotCompleted is returned when downloading an archive or migrating card
+// numbers before the export has completed
+var ErrNotCompleted = errors.New("offboarding export has not completed")
+
+// ErrMigrationRequested is returned when requesting a PAN migration that was already requested
+var ErrMigrationRequested = errors.New("PAN migration was already requested for this export")
+
+// ErrDestinationRequired is returned when requesting a PAN migration without a destination PSP
+var ErrDestinationRequired = errors.New("destination is required")
+
+// Export packages a tenant's payment data for a merchant leaving the platform
+type Export struct {
+	ID                 string    `json:"id"`
+	TenantID           string    `json:"tenant_id"`
+	Status             string    `json:"status"`
+	RecipientPublicKey string    `json:"-"` // PEM key the archive is encrypted to
+	Manifest           *Manifest `json:"manifest,omitempty"`
+	ArchiveSHA256      string    `json:"archive_sha256,omitempty"` // Of the encrypted archive, to verify transfers
+	ArchiveSize        int64     `json:"archive_size,omitempty"`
+	Error              string    `json:"error,omitempty"`
+
+	PANMigrationStatus      string     `json:"pan_migration_status,omitempty"`
+	PANMigrationDestination string     `json:"pan_migration_destination,omitempty"`
+	PANMigrationReference   string     `json:"pan_migration_reference,omitempty"` // Reference returned by the migration hook
+	PANMigrationRequestedAt *time.Time `json:"pan_migration_requested_at,omitempty"`
+
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// Manifest describes an archive's contents. It is stored inside the archive
+// and, since it holds no customer data, alongside the export.
+type Manifest struct {
+	Version   int            `json:"version"`
+	ExportID  string         `json:"export_id"`
+	TenantID  string         `json:"tenant_id"`
+	CreatedAt time.Time      `json:"created_at"`
+	Files     []ManifestFile `json:"files"`
+	Omitted   []string       `json:"omitted"` // Data the archive deliberately does not hold, and why
+}
+
+// ManifestFile describes one JSON Lines file of an archive
+type ManifestFile struct {
+	Name    string `json:"name"`
+	Records int    `json:"records"`
+	SHA256  string `json:"sha256"`
+}
+
+// CreateRequest starts an export
+type CreateRequest struct {
+	RecipientPublicKey string `json:"recipient_public_key"`
+}
+
+// MigrationRequest asks for a tenant's card numbers to be transferred to the
+// PSP the merchant is moving to
+type MigrationRequest struct {
+	Destination      string `json:"destination"`                 // The receiving PSP
+	DestinationEmail string `json:"destination_email,omitempty"` // Contact at the receiving PSP
+	EncryptionKey    string `json:"encryption_key,omitempty"`    // PGP key the receiving PSP accepts card data with
+	Notes            string `json:"notes,omitempty"`
+}
+
+// Migration is what a MigrationHook is given to start a PAN migration
+type Migration struct {
+	ExportID         string           `json:"export_id"`
+	TenantID         string           `json:"tenant_id"`
+	Provider         string           `json:"provider"` // Provider holding the card numbers
+	Request          MigrationRequest `json:"request"`
+	CustomerIDs      []string         `json:"customer_ids"`
+	PaymentMethodIDs []string         `json:"payment_method_ids"`
+}
+
+// MigrationHook starts a provider-side PAN migration. Providers transfer card
+// numbers directly to the receiving PSP on request, so hooks hand the request
+// to whoever files it and return their reference for it.
+type MigrationHook interface {
+	RequestMigration(ctx context.Context, migration *Migration) (string, error)
+}
+
+// Store persists exports and reads the tenant data they package. Getters
+// return sql.ErrNoRows when no export matches, and claiming, completing or
+// failing an export in another status does too.
+type Store interface {
+	CreateOffboardingExport(ctx context.Context, export *Export) (*Export, error)
+	GetOffboardingExport(ctx context.Context, id string) (*Export, error)
+	ListOffboardingExports(ctx context.Context, tenantID string, limit int) ([]*Export, error)
+	ListRunnableOffboardingExports(ctx context.Context, limit int) ([]*Export, error)
+	ClaimOffboardingExport(ctx context.Context, id string) (*Export, error)
+	CompleteOffboardingExport(ctx context.Context, export *Export, archive []byte) (*Export, error)
+	FailOffboardingExport(ctx context.Context, id, reason string) (*Export, error)
+	RecordOffboardingPANMigration(ctx context.Context, export *Export) (*Export, error)
+	GetOffboardingArchive(ctx context.Context, id string) ([]byte, error)
+
+	ListTenantCustomerIDs(ctx context.Context, tenantID string) ([]string, error)
+	GetCustomer(ctx context.Context, id string) (*stripe.Customer, error)
+	ListPaymentMethods(ctx context.Context, customerID string) ([]*stripe.PaymentMethod, error)
+	ListVaultTokensByCustomer(ctx context.Context, customerID string) ([]*vault.Token, error)
+	ListSubscriptions(ctx context.Context, filter mirror.SubscriptionFilter) ([]*stripe.Subscription, error)
+	ListCharges(ctx context.Context, customerID string, limit, offset int32) ([]*stripe.Charge, error)
+	ListRefunds(ctx context.Context, chargeID string, limit, offset int32) ([]*stripe.Refund, error)
+}
+
+// Config configures the export worker
+type Config struct {
+	Interval  time.Duration // How often pending exports are picked up
+	BatchSize int           // Exports built per run
+}
+
+// LoadConfig loads the export configuration from environment variables
+func LoadConfig() *Config {
+	config := &Config{
+		Interval:  30 * time.Second,
+		BatchSize: 5,
+	}
+
+	if seconds, err := strconv.Atoi(os.Getenv("OFFBOARDING_EXPORT_INTERVAL_SECONDS")); err == nil && seconds > 0 {
+		config.Interval = time.Duration(seconds) * time.Second
+	}
+	if size, err := strconv.Atoi(os.Getenv("OFFBOARDING_EXPORT_BATCH_SIZE")); err == nil && size > 0 {
+		config.BatchSize = size
+	}
+
+	return config
+}
@@ -0,0 +1,345 @@
+package offboarding
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"apis/payments/services/mirror"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// manifestVersion is the archive format version recorded in manifests
+const manifestVersion = 1
+
+// chargePageSize is how many of a customer's charges are read at a time
+const chargePageSize = 500
+
+// maxCustomerSubscriptions bounds the subscriptions exported per customer
+const maxCustomerSubscriptions = 1000
+
+// omitted lists what archives deliberately leave out
+var omitted = []string{
+	"card numbers and security codes: never stored by this service; request a PAN migration to have the provider transfer them to the new PSP",
+	"mandates: held by the provider with the payment methods they authorize, and transferred with them by a PAN migration",
+}
+
+// Service exports a tenant's customers, payment methods, subscriptions and
+// transaction history for a merchant leaving the platform. Exports are built
+// by a worker into a tar.gz archive of JSON Lines files with a manifest, and
+// encrypted to a key the merchant provides, so the stored archive can only be
+// read by them. Card numbers are left to a provider-side PAN migration.
+type Service struct {
+	store  Store
+	hook   MigrationHook
+	config *Config
+	tracer trace.Tracer
+}
+
+// NewService creates a new offboarding service
+func NewService(store Store, hook MigrationHook, config *Config) *Service {
+	return &Service{
+		store:  store,
+		hook:   hook,
+		config: config,
+		tracer: otel.Tracer("payments.offboarding"),
+	}
+}
+
+// Create queues an export of a tenant's data encrypted to the recipient key
+func (s *Service) Create(ctx context.Context, tenantID string, req CreateRequest) (*Export, error) {
+	ctx, span := s.tracer.Start(ctx, "Create")
+	defer span.End()
+
+	if _, err := ParseRecipientKey(req.RecipientPublicKey); err != nil {
+		return nil, err
+	}
+
+	return s.store.CreateOffboardingExport(ctx, &Export{
+		ID:                 "obx_" + uuid.New().String(),
+		TenantID:           tenantID,
+		Status:             StatusPending,
+		RecipientPublicKey: req.RecipientPublicKey,
+	})
+}
+
+// Get returns an export
+func (s *Service) Get(ctx context.Context, id string) (*Export, error) {
+	ctx, span := s.tracer.Start(ctx, "Get")
+	defer span.End()
+
+	return s.store.GetOffboardingExport(ctx, id)
+}
+
+// List returns a tenant's exports, newest first
+func (s *Service) List(ctx context.Context, tenantID string, limit int) ([]*Export, error) {
+	ctx, span := s.tracer.Start(ctx, "List")
+	defer span.End()
+
+	return s.store.ListOffboardingExports(ctx, tenantID, limit)
+}
+
+// Archive returns a completed export's encrypted archive
+func (s *Service) Archive(ctx context.Context, id string) (*Export, []byte, error) {
+	ctx, span := s.tracer.Start(ctx, "Archive")
+	defer span.End()
+
+	export, err := s.store.GetOffboardingExport(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if export.Status != StatusCompleted {
+		return nil, nil, ErrNotCompleted
+	}
+
+	archive, err := s.store.GetOffboardingArchive(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return export, archive, nil
+}
+
+// RunPending builds queued exports, and exports whose worker stopped
+// mid-build, returning how many completed
+func (s *Service) RunPending(ctx context.Context) (int, error) {
+	ctx, span := s.tracer.Start(ctx, "RunPending")
+	defer span.End()
+
+	exports, err := s.store.ListRunnableOffboardingExports(ctx, s.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	completed := 0
+	var errs []error
+	for _, export := range exports {
+		claimed, err := s.store.ClaimOffboardingExport(ctx, export.ID)
+		if err != nil {
+			// Claimed by another worker
+			continue
+		}
+
+		if err := s.build(ctx, claimed); err != nil {
+			errs = append(errs, fmt.Errorf("export %s: %w", claimed.ID, err))
+			if _, failErr := s.store.FailOffboardingExport(ctx, claimed.ID, err.Error()); failErr != nil {
+				errs = append(errs, fmt.Errorf("failed to record failure of export %s: %w", claimed.ID, failErr))
+			}
+			continue
+		}
+		completed++
+	}
+
+	return completed, errors.Join(errs...)
+}
+
+// RequestPANMigration asks the provider, through the migration hook, to
+// transfer the card numbers of a completed export's payment methods to the
+// merchant's new PSP. A failed request can be retried.
+func (s *Service) RequestPANMigration(ctx context.Context, id string, req MigrationRequest) (*Export, error) {
+	ctx, span := s.tracer.Start(ctx, "RequestPANMigration")
+	defer span.End()
+
+	if strings.TrimSpace(req.Destination) == "" {
+		return nil, ErrDestinationRequired
+	}
+
+	export, err := s.store.GetOffboardingExport(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if export.Status != StatusCompleted {
+		return nil, ErrNotCompleted
+	}
+	if export.PANMigrationStatus == MigrationRequested {
+		return nil, ErrMigrationRequested
+	}
+
+	migration := &Migration{
+		ExportID: export.ID,
+		TenantID: export.TenantID,
+		Provider: "stripe",
+		Request:  req,
+	}
+	migration.CustomerIDs, err = s.store.ListTenantCustomerIDs(ctx, export.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	for _, customerID := range migration.CustomerIDs {
+		paymentMethods, err := s.store.ListPaymentMethods(ctx, customerID)
+		if err != nil {
+			return nil, err
+		}
+		for _, paymentMethod := range paymentMethods {
+			migration.PaymentMethodIDs = append(migration.PaymentMethodIDs, paymentMethod.ID)
+		}
+	}
+
+	reference, hookErr := s.hook.RequestMigration(ctx, migration)
+	export.PANMigrationStatus = MigrationRequested
+	if hookErr != nil {
+		export.PANMigrationStatus = MigrationFailed
+	}
+	export.PANMigrationDestination = req.Destination
+	export.PANMigrationReference = reference
+
+	recorded, err := s.store.RecordOffboardingPANMigration(ctx, export)
+	if err != nil {
+		return nil, err
+	}
+	if hookErr != nil {
+		return recorded, fmt.Errorf("failed to request PAN migration: %w", hookErr)
+	}
+	return recorded, nil
+}
+
+// Start builds pending exports every configured interval until the returned
+// stop function is called
+func (s *Service) Start() (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(s.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				completed, err := s.RunPending(context.Background())
+				if err != nil {
+					log.Printf("Offboarding export run failed: %v", err)
+				}
+				if completed > 0 {
+					log.Printf("Completed %d offboarding exports", completed)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// build packages a claimed export's tenant data, encrypts it and stores it
+func (s *Service) build(ctx context.Context, export *Export) error {
+	recipient, err := ParseRecipientKey(export.RecipientPublicKey)
+	if err != nil {
+		return err
+	}
+
+	customerIDs, err := s.store.ListTenantCustomerIDs(ctx, export.TenantID)
+	if err != nil {
+		return err
+	}
+
+	var customers, paymentMethods, vaultTokens, subscriptions, charges, refunds []any
+	for _, customerID := range customerIDs {
+		customer, err := s.store.GetCustomer(ctx, customerID)
+		if err != nil {
+			return fmt.Errorf("failed to read customer %s: %w", customerID, err)
+		}
+		customers = append(customers, customer)
+
+		methods, err := s.store.ListPaymentMethods(ctx, customerID)
+		if err != nil {
+			return err
+		}
+		for _, method := range methods {
+			paymentMethods = append(paymentMethods, method)
+		}
+
+		tokens, err := s.store.ListVaultTokensByCustomer(ctx, customerID)
+		if err != nil {
+			return err
+		}
+		for _, token := range tokens {
+			vaultTokens = append(vaultTokens, token)
+		}
+
+		customerSubscriptions, err := s.store.ListSubscriptions(ctx, mirror.SubscriptionFilter{CustomerID: customerID, Limit: maxCustomerSubscriptions})
+		if err != nil {
+			return err
+		}
+		for _, subscription := range customerSubscriptions {
+			subscriptions = append(subscriptions, subscription)
+		}
+
+		for offset := int32(0); ; offset += chargePageSize {
+			page, err := s.store.ListCharges(ctx, customerID, chargePageSize, offset)
+			if err != nil {
+				return err
+			}
+			for _, charge := range page {
+				charges = append(charges, charge)
+				if charge.AmountRefunded == 0 {
+					continue
+				}
+				chargeRefunds, err := s.store.ListRefunds(ctx, charge.ID, chargePageSize, 0)
+				if err != nil {
+					return err
+				}
+				for _, refund := range chargeRefunds {
+					refunds = append(refunds, refund)
+				}
+			}
+			if len(page) < chargePageSize {
+				break
+			}
+		}
+	}
+
+	manifest := &Manifest{
+		Version:   manifestVersion,
+		ExportID:  export.ID,
+		TenantID:  export.TenantID,
+		CreatedAt: time.Now().UTC(),
+		Omitted:   omitted,
+	}
+	writer := newArchiveWriter(manifest)
+	files := []struct {
+		name    string
+		records []any
+	}{
+		{"customers.jsonl", customers},
+		{"payment_methods.jsonl", paymentMethods},
+		{"vault_tokens.jsonl", vaultTokens},
+		{"subscriptions.jsonl", subscriptions},
+		{"charges.jsonl", charges},
+		{"refunds.jsonl", refunds},
+	}
+	for _, file := range files {
+		if err := writer.WriteRecords(file.name, file.records); err != nil {
+			return err
+		}
+	}
+	archive, err := writer.Close()
+	if err != nil {
+		return err
+	}
+
+	sealed, err := Seal(archive, recipient)
+	if err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(sealed)
+	export.Manifest = manifest
+	export.ArchiveSHA256 = hex.EncodeToString(sum[:])
+	export.ArchiveSize = int64(len(sealed))
+
+	_, err = s.store.CompleteOffboardingExport(ctx, export, sealed)
+	return err
+}
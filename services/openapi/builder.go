@@ -0,0 +1,418 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ErrorSchema names the component schema of error responses
+const ErrorSchema = "Error"
+
+// methods are the HTTP methods documented, in the order they are listed
+var methods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	rawJSONType = reflect.TypeOf(json.RawMessage{})
+)
+
+// Route is a registered route
+type Route struct {
+	Method string
+	Path   string // Router path, with :name parameters
+}
+
+// Spec describes an operation beyond its route. Request and Response are
+// values of the Go types of the JSON bodies, such as stripe.ChargeRequest{}.
+type Spec struct {
+	Summary    string
+	Request    any
+	Response   any
+	Status     int         // Success status; 200 unless set
+	List       bool        // Response is a page of Response objects
+	Query      []Parameter // Query parameters besides the page's
+	Deprecated bool
+}
+
+// Builder builds a document from an API's routes, described by specs
+type Builder struct {
+	info   Info
+	prefix string
+	specs  map[string]Spec
+
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+// NewBuilder creates a builder for the routes below prefix
+func NewBuilder(info Info, prefix string) *Builder {
+	builder := &Builder{
+		info:   info,
+		prefix: strings.TrimSuffix(prefix, "/"),
+		specs:  make(map[string]Spec),
+	}
+	builder.reset()
+	return builder
+}
+
+// Describe sets the spec of a route, given its router path
+func (b *Builder) Describe(method, path string, spec Spec) {
+	b.specs[strings.ToUpper(method)+" "+b.trimPath(path)] = spec
+}
+
+// Build returns a document of the routes below the prefix. Routes without a
+// spec are listed with untyped bodies. Every operation can fail with the
+// error schema.
+func (b *Builder) Build(routes []Route) *Document {
+	b.reset()
+
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    b.info,
+		Servers: []Server{{URL: b.prefix}},
+		Paths:   make(map[string]PathItem),
+		Components: Components{
+			Schemas:   b.schemas,
+			Responses: errorResponses(),
+			SecuritySchemes: map[string]*SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer", Description: "API key secret or JWT"},
+			},
+		},
+		Security: []map[string][]string{{"bearerAuth": {}}},
+	}
+
+	tags := make(map[string]bool)
+	for _, route := range sortRoutes(routes) {
+		if !strings.HasPrefix(route.Path, b.prefix+"/") {
+			continue
+		}
+		path := b.trimPath(route.Path)
+		method := strings.ToLower(route.Method)
+		openAPIPath := convertPath(path)
+
+		item, ok := doc.Paths[openAPIPath]
+		if !ok {
+			item = make(PathItem)
+			doc.Paths[openAPIPath] = item
+		}
+		if item[method] != nil {
+			continue
+		}
+
+		operation := b.operation(route.Method, path, b.specs[route.Method+" "+path])
+		item[method] = operation
+		for _, tag := range operation.Tags {
+			tags[tag] = true
+		}
+	}
+
+	for tag := range tags {
+		doc.Tags = append(doc.Tags, Tag{Name: tag})
+	}
+	sort.Slice(doc.Tags, func(i, j int) bool { return doc.Tags[i].Name < doc.Tags[j].Name })
+
+	return doc
+}
+
+// operation builds the operation of a route
+func (b *Builder) operation(method, path string, spec Spec) *Operation {
+	operation := &Operation{
+		OperationID: operationID(method, path),
+		Summary:     spec.Summary,
+		Deprecated:  spec.Deprecated,
+		Responses:   make(map[string]*Response),
+	}
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	operation.Tags = []string{segments[0]}
+
+	hasPathParameters := false
+	for _, segment := range segments {
+		if name, ok := strings.CutPrefix(segment, ":"); ok {
+			hasPathParameters = true
+			operation.Parameters = append(operation.Parameters, Parameter{
+				Name:     strings.TrimSuffix(name, "?"),
+				In:       "path",
+				Required: true,
+				Schema:   &Schema{Type: "string"},
+			})
+		}
+	}
+	if spec.List {
+		operation.Parameters = append(operation.Parameters,
+			Parameter{Name: "limit", In: "query", Description: "Page size", Schema: &Schema{Type: "integer"}},
+			Parameter{Name: "cursor", In: "query", Description: "next_cursor of the previous page", Schema: &Schema{Type: "string"}},
+		)
+	}
+	operation.Parameters = append(operation.Parameters, spec.Query...)
+
+	if spec.Request != nil {
+		operation.RequestBody = &RequestBody{
+			Required: true,
+			Content:  jsonContent(b.SchemaOf(reflect.TypeOf(spec.Request))),
+		}
+	} else if method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch {
+		operation.RequestBody = &RequestBody{Content: jsonContent(&Schema{Type: "object"})}
+	}
+
+	status := spec.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := &Response{Description: http.StatusText(status)}
+	switch {
+	case spec.Response != nil && spec.List:
+		success.Content = jsonContent(pageSchema(b.SchemaOf(reflect.TypeOf(spec.Response))))
+	case spec.Response != nil:
+		success.Content = jsonContent(b.SchemaOf(reflect.TypeOf(spec.Response)))
+	case status != http.StatusNoContent:
+		success.Content = jsonContent(&Schema{Type: "object"})
+	}
+	operation.Responses[strconv.Itoa(status)] = success
+
+	operation.Responses["400"] = &Response{Ref: "#/components/responses/BadRequest"}
+	operation.Responses["401"] = &Response{Ref: "#/components/responses/Unauthorized"}
+	operation.Responses["403"] = &Response{Ref: "#/components/responses/Forbidden"}
+	if hasPathParameters {
+		operation.Responses["404"] = &Response{Ref: "#/components/responses/NotFound"}
+	}
+	operation.Responses["429"] = &Response{Ref: "#/components/responses/TooManyRequests"}
+	operation.Responses["500"] = &Response{Ref: "#/components/responses/InternalError"}
+
+	return operation
+}
+
+// SchemaOf returns the schema of a Go type as JSON encodes it. Named structs
+// become component schemas and are referenced. Fields are required when
+// validated as required.
+func (b *Builder) SchemaOf(t reflect.Type) *Schema {
+	if t.Kind() == reflect.Pointer {
+		schema := b.SchemaOf(t.Elem())
+		if schema.Ref == "" {
+			schema.Nullable = true
+		}
+		return schema
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawJSONType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: b.SchemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.SchemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + b.register(t)}
+	default:
+		// Interfaces may hold any value
+		return &Schema{}
+	}
+}
+
+// register adds a named struct to the component schemas and returns its
+// name. Types named alike in different packages are told apart by package.
+func (b *Builder) register(t reflect.Type) string {
+	if name, ok := b.names[t]; ok {
+		return name
+	}
+
+	name := t.Name()
+	if _, taken := b.schemas[name]; taken {
+		pkg := t.PkgPath()
+		pkg = pkg[strings.LastIndex(pkg, "/")+1:]
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+
+	// Registered before its fields so self-references resolve
+	b.names[t] = name
+	b.schemas[name] = &Schema{}
+	*b.schemas[name] = *b.structSchema(t)
+	return name
+}
+
+// structSchema returns the schema of a struct's JSON fields, flattening
+// embedded structs as encoding/json does
+func (b *Builder) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				flattened := b.structSchema(embedded)
+				for property, propertySchema := range flattened.Properties {
+					schema.Properties[property] = propertySchema
+				}
+				schema.Required = append(schema.Required, flattened.Required...)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := b.SchemaOf(field.Type)
+		rules := strings.Split(field.Tag.Get("validate"), ",")
+		for _, rule := range rules {
+			if rule == "required" {
+				schema.Required = append(schema.Required, name)
+			}
+			if values, ok := strings.CutPrefix(rule, "oneof="); ok && property.Type == "string" {
+				property.Enum = strings.Fields(values)
+			}
+		}
+		schema.Properties[name] = property
+	}
+
+	sort.Strings(schema.Required)
+	return schema
+}
+
+// reset starts the component schemas over with the error schema
+func (b *Builder) reset() {
+	b.schemas = map[string]*Schema{
+		ErrorSchema: {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"error":           {Type: "string", Description: "What went wrong"},
+				"display_message": {Type: "string", Description: "Customer-safe message in the Accept-Language language"},
+				"request_id":      {Type: "string", Description: "The X-Request-ID of the request, to quote when reporting the error"},
+			},
+			Required: []string{"error", "display_message"},
+		},
+	}
+	b.names = make(map[reflect.Type]string)
+}
+
+// trimPath returns a router path below the prefix, without a trailing slash
+func (b *Builder) trimPath(path string) string {
+	path = strings.TrimPrefix(path, b.prefix)
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	return path
+}
+
+// errorResponses are the shared error responses, all with the error schema
+func errorResponses() map[string]*Response {
+	content := jsonContent(&Schema{Ref: "#/components/schemas/" + ErrorSchema})
+	return map[string]*Response{
+		"BadRequest":      {Description: "The request is invalid", Content: content},
+		"Unauthorized":    {Description: "Credentials are missing or invalid", Content: content},
+		"Forbidden":       {Description: "Credentials do not allow this action, or the tenant is suspended", Content: content},
+		"NotFound":        {Description: "The resource does not exist", Content: content},
+		"TooManyRequests": {Description: "The rate limit is exceeded; retry after Retry-After seconds", Content: content},
+		"InternalError":   {Description: "The request failed unexpectedly", Content: content},
+	}
+}
+
+// pageSchema is the schema of a page of items
+func pageSchema(items *Schema) *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"data":        {Type: "array", Items: items},
+			"has_more":    {Type: "boolean"},
+			"next_cursor": {Type: "string", Description: "Cursor of the next page, when has_more is set"},
+		},
+		Required: []string{"data"},
+	}
+}
+
+// jsonContent is the content of a JSON body
+func jsonContent(schema *Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: schema}}
+}
+
+// convertPath converts :name parameters to {name}
+func convertPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if name, ok := strings.CutPrefix(segment, ":"); ok {
+			segments[i] = "{" + strings.TrimSuffix(name, "?") + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// operationID names an operation after its method and path, such as
+// getCustomersById for GET /customers/:id
+func operationID(method, path string) string {
+	var id strings.Builder
+	id.WriteString(strings.ToLower(method))
+	for _, segment := range strings.Split(strings.Trim(path, "/"), "/") {
+		if name, ok := strings.CutPrefix(segment, ":"); ok {
+			id.WriteString("By")
+			segment = strings.TrimSuffix(name, "?")
+		}
+		for _, word := range strings.FieldsFunc(segment, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			id.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return id.String()
+}
+
+// sortRoutes orders routes by path, then by method, keeping the documented
+// methods only
+func sortRoutes(routes []Route) []Route {
+	order := make(map[string]int, len(methods))
+	for i, method := range methods {
+		order[method] = i + 1
+	}
+
+	sorted := make([]Route, 0, len(routes))
+	for _, route := range routes {
+		if order[route.Method] > 0 {
+			sorted = append(sorted, route)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Path != sorted[j].Path {
+			return sorted[i].Path < sorted[j].Path
+		}
+		return order[sorted[i].Method] < order[sorted[j].Method]
+	})
+	return sorted
+}
@@ -0,0 +1,31 @@
+package openapi
+
+import (
+	"fmt"
+	"html"
+)
+
+// swaggerUIVersion is the Swagger UI release loaded from the CDN
+const swaggerUIVersion = "5.17.14"
+
+// SwaggerUI returns a page rendering the document at specURL with Swagger UI
+func SwaggerUI(title, specURL string) string {
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>%[1]s</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@%[3]s/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@%[3]s/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({ url: %[2]q, dom_id: "#swagger-ui" });
+    };
+  </script>
+</body>
+</html>
+`, html.EscapeString(title), specURL, swaggerUIVersion)
+}
@@ -0,0 +1,1040 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"apis/payments/services/egress"
+	"apis/payments/services/receipts"
+)
+
+// Paddle Billing API hosts
+const (
+	paddleProductionURL = "https://api.paddle.com"
+	paddleSandboxURL    = "https://sandbox-api.paddle.com"
+)
+
+// PaddleGateway implements the PaymentGateway interface for Paddle Billing.
+// Paddle is a merchant of record: it collects payment through its own
+// checkout, so charges and new subscriptions are created as transactions
+// the customer completes, and it has no authorize-only charges.
+type PaddleGateway struct {
+	apiKey        string
+	webhookSecret string
+	baseURL       string
+	client        *http.Client
+	handlers      map[string][]PaddleEventHandler
+}
+
+// NewPaddleGateway creates a new Paddle payment gateway instance. Calls
+// leave through the paddle egress settings; base_url overrides the API host.
+func NewPaddleGateway(config map[string]interface{}) (*PaddleGateway, error) {
+	if err := validatePaddleConfig(config); err != nil {
+		return nil, err
+	}
+
+	baseURL := paddleSandboxURL
+	if config["environment"] == "production" {
+		baseURL = paddleProductionURL
+	}
+	if override, ok := config["base_url"].(string); ok && override != "" {
+		baseURL = strings.TrimSuffix(override, "/")
+	}
+
+	egressConfig, err := egress.LoadConfig("paddle")
+	if err != nil {
+		return nil, &InvalidConfigError{Message: err.Error()}
+	}
+	transport, err := egress.NewTransport(egressConfig)
+	if err != nil {
+		return nil, &InvalidConfigError{Message: err.Error()}
+	}
+
+	webhookSecret, _ := config["webhook_secret"].(string)
+
+	return &PaddleGateway{
+		apiKey:        config["api_key"].(string),
+		webhookSecret: webhookSecret,
+		baseURL:       baseURL,
+		client:        &http.Client{Transport: transport, Timeout: egressConfig.RequestTimeout},
+		handlers:      make(map[string][]PaddleEventHandler),
+	}, nil
+}
+
+// GetProvider returns the provider name
+func (g *PaddleGateway) GetProvider() string {
+	return "paddle"
+}
+
+// GetCapabilities returns the capabilities supported by Paddle. Disputes are
+// handled by Paddle as merchant of record and tax is always calculated.
+func (g *PaddleGateway) GetCapabilities() GatewayCapabilities {
+	return GatewayCapabilities{
+		SupportsCustomers:     true,
+		SupportsCharges:       true,
+		SupportsRefunds:       true,
+		SupportsSubscriptions: true,
+		SupportsDisputes:      false,
+		SupportsConnect:       false,
+		SupportsTax:           true,
+		MaxChargeAmount:       99999999,
+		MinChargeAmount:       70, // $0.70 in cents
+		SupportedCurrencies: []string{
+			"usd", "eur", "gbp", "jpy", "aud", "cad", "chf", "hkd", "sgd", "sek", "ars", "brl", "cny", "cop",
+			"czk", "dkk", "huf", "ils", "inr", "krw", "mxn", "nok", "nzd", "pln", "thb", "try", "twd", "uah", "zar",
+		},
+	}
+}
+
+// Paddle API resources
+
+type paddleCustomer struct {
+	ID         string                 `json:"id"`
+	Name       *string                `json:"name"`
+	Email      string                 `json:"email"`
+	Status     string                 `json:"status"`
+	CustomData map[string]interface{} `json:"custom_data"`
+	CreatedAt  time.Time              `json:"created_at"`
+	UpdatedAt  time.Time              `json:"updated_at"`
+}
+
+type paddleAddress struct {
+	ID          string  `json:"id,omitempty"`
+	CountryCode string  `json:"country_code"`
+	PostalCode  *string `json:"postal_code,omitempty"`
+	Region      *string `json:"region,omitempty"`
+	City        *string `json:"city,omitempty"`
+	FirstLine   *string `json:"first_line,omitempty"`
+	SecondLine  *string `json:"second_line,omitempty"`
+}
+
+type paddlePaymentMethod struct {
+	ID         string `json:"id"`
+	CustomerID string `json:"customer_id"`
+	Type       string `json:"type"`
+	Card       *struct {
+		Type        string `json:"type"`
+		Last4       string `json:"last4"`
+		ExpiryMonth int    `json:"expiry_month"`
+		ExpiryYear  int    `json:"expiry_year"`
+	} `json:"card"`
+	SavedAt time.Time `json:"saved_at"`
+}
+
+type paddleTotals struct {
+	Total string `json:"total"`
+}
+
+type paddleLineItem struct {
+	ID       string       `json:"id"`
+	PriceID  string       `json:"price_id"`
+	Quantity int          `json:"quantity"`
+	Totals   paddleTotals `json:"totals"`
+}
+
+type paddleTransaction struct {
+	ID             string                 `json:"id"`
+	Status         string                 `json:"status"`
+	CustomerID     *string                `json:"customer_id"`
+	SubscriptionID *string                `json:"subscription_id"`
+	CurrencyCode   string                 `json:"currency_code"`
+	CustomData     map[string]interface{} `json:"custom_data"`
+	Details        struct {
+		Totals    paddleTotals     `json:"totals"`
+		LineItems []paddleLineItem `json:"line_items"`
+	} `json:"details"`
+	Payments []struct {
+		PaymentMethodID *string `json:"payment_method_id"`
+		Status          string  `json:"status"`
+	} `json:"payments"`
+	Checkout *struct {
+		URL *string `json:"url"`
+	} `json:"checkout"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type paddleAdjustment struct {
+	ID            string       `json:"id"`
+	Action        string       `json:"action"`
+	TransactionID string       `json:"transaction_id"`
+	Reason        string       `json:"reason"`
+	Status        string       `json:"status"`
+	CurrencyCode  string       `json:"currency_code"`
+	Totals        paddleTotals `json:"totals"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     *time.Time   `json:"updated_at"`
+}
+
+type paddleSubscription struct {
+	ID         string                 `json:"id"`
+	Status     string                 `json:"status"`
+	CustomerID string                 `json:"customer_id"`
+	CustomData map[string]interface{} `json:"custom_data"`
+	Items      []struct {
+		Price struct {
+			ID string `json:"id"`
+		} `json:"price"`
+	} `json:"items"`
+	CurrentBillingPeriod *struct {
+		StartsAt time.Time `json:"starts_at"`
+		EndsAt   time.Time `json:"ends_at"`
+	} `json:"current_billing_period"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type paddlePagination struct {
+	HasMore        bool `json:"has_more"`
+	EstimatedTotal int  `json:"estimated_total"`
+}
+
+// Customer management implementation
+
+func (g *PaddleGateway) CreateCustomer(ctx context.Context, req CreateCustomerRequest) (*Customer, error) {
+	body := map[string]interface{}{"email": req.Email}
+	if req.Name != "" {
+		body["name"] = req.Name
+	}
+	if req.Metadata != nil {
+		body["custom_data"] = req.Metadata
+	}
+
+	var paddleCustomer paddleCustomer
+	if err := g.do(ctx, http.MethodPost, "/customers", nil, body, &paddleCustomer, nil); err != nil {
+		return nil, g.paymentError("customer_creation_failed", "failed to create customer", err)
+	}
+
+	customer := convertPaddleCustomer(&paddleCustomer)
+	if req.Address != nil {
+		address, err := g.createAddress(ctx, paddleCustomer.ID, req.Address)
+		if err != nil {
+			return nil, g.paymentError("customer_creation_failed", "failed to add customer address", err)
+		}
+		customer.Address = address
+	}
+
+	return customer, nil
+}
+
+func (g *PaddleGateway) GetCustomer(ctx context.Context, customerID string) (*Customer, error) {
+	var paddleCustomer paddleCustomer
+	if err := g.do(ctx, http.MethodGet, "/customers/"+url.PathEscape(customerID), nil, nil, &paddleCustomer, nil); err != nil {
+		return nil, g.paymentError("customer_retrieval_failed", "failed to retrieve customer", err)
+	}
+
+	customer := convertPaddleCustomer(&paddleCustomer)
+	address, err := g.activeAddress(ctx, customerID)
+	if err != nil {
+		return nil, g.paymentError("customer_retrieval_failed", "failed to retrieve customer address", err)
+	}
+	customer.Address = address
+
+	return customer, nil
+}
+
+func (g *PaddleGateway) UpdateCustomer(ctx context.Context, customerID string, req UpdateCustomerRequest) (*Customer, error) {
+	body := map[string]interface{}{}
+	if req.Email != "" {
+		body["email"] = req.Email
+	}
+	if req.Name != "" {
+		body["name"] = req.Name
+	}
+	if req.Metadata != nil {
+		body["custom_data"] = req.Metadata
+	}
+
+	var paddleCustomer paddleCustomer
+	if err := g.do(ctx, http.MethodPatch, "/customers/"+url.PathEscape(customerID), nil, body, &paddleCustomer, nil); err != nil {
+		return nil, g.paymentError("customer_update_failed", "failed to update customer", err)
+	}
+
+	customer := convertPaddleCustomer(&paddleCustomer)
+	if req.Address != nil {
+		address, err := g.createAddress(ctx, customerID, req.Address)
+		if err != nil {
+			return nil, g.paymentError("customer_update_failed", "failed to add customer address", err)
+		}
+		customer.Address = address
+	}
+
+	return customer, nil
+}
+
+// DeleteCustomer archives the customer; Paddle keeps customers for its records
+func (g *PaddleGateway) DeleteCustomer(ctx context.Context, customerID string) error {
+	body := map[string]interface{}{"status": "archived"}
+	if err := g.do(ctx, http.MethodPatch, "/customers/"+url.PathEscape(customerID), nil, body, nil, nil); err != nil {
+		return g.paymentError("customer_deletion_failed", "failed to archive customer", err)
+	}
+
+	return nil
+}
+
+func (g *PaddleGateway) ListCustomers(ctx context.Context, req ListCustomersRequest) (*CustomerList, error) {
+	query := g.pageQuery(req.Limit, req.Cursor)
+	query.Set("status", "active")
+	if req.Email != "" {
+		query.Set("email", req.Email)
+	}
+
+	var paddleCustomers []paddleCustomer
+	var pagination paddlePagination
+	if err := g.do(ctx, http.MethodGet, "/customers", query, nil, &paddleCustomers, &pagination); err != nil {
+		return nil, g.paymentError("customer_list_failed", "failed to list customers", err)
+	}
+
+	customers := make([]*Customer, len(paddleCustomers))
+	for i := range paddleCustomers {
+		customers[i] = convertPaddleCustomer(&paddleCustomers[i])
+	}
+
+	list := &CustomerList{
+		Customers: customers,
+		Total:     pagination.EstimatedTotal,
+		HasMore:   pagination.HasMore,
+	}
+	if list.HasMore && len(customers) > 0 {
+		list.NextCursor = customers[len(customers)-1].ID
+	}
+
+	return list, nil
+}
+
+// AddPaymentMethod is not supported: Paddle saves payment methods when a
+// customer completes checkout, never from card details sent by the server
+func (g *PaddleGateway) AddPaymentMethod(ctx context.Context, customerID string, req AddPaymentMethodRequest) (*PaymentMethod, error) {
+	return nil, g.notSupported("paddle saves payment methods at checkout; they cannot be added through the API")
+}
+
+func (g *PaddleGateway) RemovePaymentMethod(ctx context.Context, customerID string, paymentMethodID string) error {
+	path := "/customers/" + url.PathEscape(customerID) + "/payment-methods/" + url.PathEscape(paymentMethodID)
+	if err := g.do(ctx, http.MethodDelete, path, nil, nil, nil, nil); err != nil {
+		return g.paymentError("payment_method_removal_failed", "failed to remove payment method", err)
+	}
+
+	return nil
+}
+
+func (g *PaddleGateway) ListPaymentMethods(ctx context.Context, customerID string, req ListPaymentMethodsRequest) (*PaymentMethodList, error) {
+	var paddleMethods []paddlePaymentMethod
+	var pagination paddlePagination
+	path := "/customers/" + url.PathEscape(customerID) + "/payment-methods"
+	if err := g.do(ctx, http.MethodGet, path, g.pageQuery(req.Limit, req.Cursor), nil, &paddleMethods, &pagination); err != nil {
+		return nil, g.paymentError("payment_method_list_failed", "failed to list payment methods", err)
+	}
+
+	paymentMethods := make([]*PaymentMethod, len(paddleMethods))
+	for i := range paddleMethods {
+		paymentMethods[i] = convertPaddlePaymentMethod(&paddleMethods[i])
+	}
+
+	list := &PaymentMethodList{PaymentMethods: paymentMethods, HasMore: pagination.HasMore}
+	if list.HasMore && len(paymentMethods) > 0 {
+		list.NextCursor = paymentMethods[len(paymentMethods)-1].ID
+	}
+
+	return list, nil
+}
+
+// Payment processing implementation
+
+// CreateCharge creates a transaction for a one-off item at the given amount.
+// The customer pays it through Paddle checkout, whose URL is returned in the
+// charge metadata as checkout_url; the charge is pending until then.
+func (g *PaddleGateway) CreateCharge(ctx context.Context, req CreateChargeRequest) (*Charge, error) {
+	if !req.Capture {
+		return nil, g.notSupported("paddle does not support authorize-only charges")
+	}
+	if req.PaymentMethodID != "" {
+		return nil, g.notSupported("paddle only charges saved payment methods for subscriptions")
+	}
+	// Paddle is the merchant of record, so statements show its descriptor
+	if err := receipts.ValidateDescriptor("paddle", req.StatementDescriptor, req.StatementDescriptorSuffix); err != nil {
+		return nil, g.notSupported(err.Error())
+	}
+
+	name := req.Description
+	if name == "" {
+		name = "Charge"
+	}
+	body := map[string]interface{}{
+		"items": []map[string]interface{}{{
+			"quantity": 1,
+			"price": map[string]interface{}{
+				"description": name,
+				"unit_price": map[string]interface{}{
+					"amount":        strconv.FormatInt(req.Amount, 10),
+					"currency_code": strings.ToUpper(req.Currency),
+				},
+				"product": map[string]interface{}{
+					"name":         name,
+					"tax_category": "standard",
+				},
+			},
+		}},
+		"currency_code":   strings.ToUpper(req.Currency),
+		"collection_mode": "automatic",
+	}
+	if req.CustomerID != "" {
+		body["customer_id"] = req.CustomerID
+	}
+	if req.Metadata != nil {
+		body["custom_data"] = req.Metadata
+	}
+
+	var transaction paddleTransaction
+	if err := g.do(ctx, http.MethodPost, "/transactions", nil, body, &transaction, nil); err != nil {
+		return nil, g.paymentError("charge_creation_failed", "failed to create charge", err)
+	}
+
+	return convertPaddleTransaction(&transaction), nil
+}
+
+func (g *PaddleGateway) GetCharge(ctx context.Context, chargeID string) (*Charge, error) {
+	transaction, err := g.getTransaction(ctx, chargeID)
+	if err != nil {
+		return nil, g.paymentError("charge_retrieval_failed", "failed to retrieve charge", err)
+	}
+
+	return convertPaddleTransaction(transaction), nil
+}
+
+// UpdateCharge updates the transaction's custom data. Paddle transactions
+// have no description once created, so only metadata is updated.
+func (g *PaddleGateway) UpdateCharge(ctx context.Context, chargeID string, req UpdateChargeRequest) (*Charge, error) {
+	if req.Metadata == nil {
+		return g.GetCharge(ctx, chargeID)
+	}
+
+	body := map[string]interface{}{"custom_data": req.Metadata}
+	var transaction paddleTransaction
+	if err := g.do(ctx, http.MethodPatch, "/transactions/"+url.PathEscape(chargeID), nil, body, &transaction, nil); err != nil {
+		return nil, g.paymentError("charge_update_failed", "failed to update charge", err)
+	}
+
+	return convertPaddleTransaction(&transaction), nil
+}
+
+// CaptureCharge is not supported: Paddle captures payment at checkout
+func (g *PaddleGateway) CaptureCharge(ctx context.Context, chargeID string, req CaptureChargeRequest) (*Charge, error) {
+	return nil, g.notSupported("paddle does not support authorize-only charges")
+}
+
+// VoidCharge is not supported: Paddle captures payment at checkout
+func (g *PaddleGateway) VoidCharge(ctx context.Context, chargeID string) (*Charge, error) {
+	return nil, g.notSupported("paddle does not support authorize-only charges")
+}
+
+func (g *PaddleGateway) ListCharges(ctx context.Context, req ListChargesRequest) (*ChargeList, error) {
+	query := g.pageQuery(req.Limit, req.Cursor)
+	if req.CustomerID != "" {
+		query.Set("customer_id", req.CustomerID)
+	}
+	if req.Status != "" {
+		query.Set("status", strings.Join(paddleTransactionStatuses(req.Status), ","))
+	}
+
+	var transactions []paddleTransaction
+	var pagination paddlePagination
+	if err := g.do(ctx, http.MethodGet, "/transactions", query, nil, &transactions, &pagination); err != nil {
+		return nil, g.paymentError("charge_list_failed", "failed to list charges", err)
+	}
+
+	charges := make([]*Charge, len(transactions))
+	for i := range transactions {
+		charges[i] = convertPaddleTransaction(&transactions[i])
+	}
+
+	list := &ChargeList{
+		Charges: charges,
+		Total:   pagination.EstimatedTotal,
+		HasMore: pagination.HasMore,
+	}
+	if list.HasMore && len(charges) > 0 {
+		list.NextCursor = charges[len(charges)-1].ID
+	}
+
+	return list, nil
+}
+
+// Refund processing implementation
+
+// CreateRefund creates a refund adjustment. Paddle refunds line items, so a
+// partial amount is spread over the transaction's items in order. Refunds
+// start pending until Paddle approves them.
+func (g *PaddleGateway) CreateRefund(ctx context.Context, req CreateRefundRequest) (*Refund, error) {
+	transaction, err := g.getTransaction(ctx, req.ChargeID)
+	if err != nil {
+		return nil, g.paymentError("refund_creation_failed", "failed to retrieve charge", err)
+	}
+
+	items, err := paddleRefundItems(transaction, req.Amount)
+	if err != nil {
+		return nil, g.paymentError("refund_creation_failed", "failed to create refund", err)
+	}
+
+	reason := req.Reason
+	if reason == "" {
+		reason = "requested_by_customer"
+	}
+	body := map[string]interface{}{
+		"action":         "refund",
+		"transaction_id": req.ChargeID,
+		"reason":         reason,
+		"items":          items,
+	}
+
+	var adjustment paddleAdjustment
+	if err := g.do(ctx, http.MethodPost, "/adjustments", nil, body, &adjustment, nil); err != nil {
+		return nil, g.paymentError("refund_creation_failed", "failed to create refund", err)
+	}
+
+	return convertPaddleAdjustment(&adjustment), nil
+}
+
+func (g *PaddleGateway) GetRefund(ctx context.Context, refundID string) (*Refund, error) {
+	query := url.Values{"id": {refundID}, "action": {"refund"}}
+
+	var adjustments []paddleAdjustment
+	if err := g.do(ctx, http.MethodGet, "/adjustments", query, nil, &adjustments, nil); err != nil {
+		return nil, g.paymentError("refund_retrieval_failed", "failed to retrieve refund", err)
+	}
+	if len(adjustments) == 0 {
+		return nil, &PaymentError{Code: "refund_retrieval_failed", Message: "refund not found: " + refundID, Provider: "paddle"}
+	}
+
+	return convertPaddleAdjustment(&adjustments[0]), nil
+}
+
+// UpdateRefund is not supported: Paddle adjustments cannot be changed
+func (g *PaddleGateway) UpdateRefund(ctx context.Context, refundID string, req UpdateRefundRequest) (*Refund, error) {
+	return nil, g.notSupported("paddle refunds cannot be updated")
+}
+
+func (g *PaddleGateway) ListRefunds(ctx context.Context, req ListRefundsRequest) (*RefundList, error) {
+	query := g.pageQuery(req.Limit, req.Cursor)
+	query.Set("action", "refund")
+	if req.ChargeID != "" {
+		query.Set("transaction_id", req.ChargeID)
+	}
+
+	var adjustments []paddleAdjustment
+	var pagination paddlePagination
+	if err := g.do(ctx, http.MethodGet, "/adjustments", query, nil, &adjustments, &pagination); err != nil {
+		return nil, g.paymentError("refund_list_failed", "failed to list refunds", err)
+	}
+
+	refunds := make([]*Refund, len(adjustments))
+	for i := range adjustments {
+		refunds[i] = convertPaddleAdjustment(&adjustments[i])
+	}
+
+	list := &RefundList{
+		Refunds: refunds,
+		Total:   pagination.EstimatedTotal,
+		HasMore: pagination.HasMore,
+	}
+	if list.HasMore && len(refunds) > 0 {
+		list.NextCursor = refunds[len(refunds)-1].ID
+	}
+
+	return list, nil
+}
+
+// Subscription management implementation
+
+// CreateSubscription creates a transaction for the plan's recurring price.
+// Paddle creates the subscription once the customer completes checkout, so
+// the returned subscription has no ID yet and is incomplete; its metadata
+// holds the transaction_id and checkout_url. The subscription.created
+// webhook carries the same custom data.
+func (g *PaddleGateway) CreateSubscription(ctx context.Context, req CreateSubscriptionRequest) (*Subscription, error) {
+	body := map[string]interface{}{
+		"items":           []map[string]interface{}{{"price_id": req.PlanID, "quantity": 1}},
+		"customer_id":     req.CustomerID,
+		"collection_mode": "automatic",
+	}
+	if req.Metadata != nil {
+		body["custom_data"] = req.Metadata
+	}
+
+	var transaction paddleTransaction
+	if err := g.do(ctx, http.MethodPost, "/transactions", nil, body, &transaction, nil); err != nil {
+		return nil, g.paymentError("subscription_creation_failed", "failed to create subscription", err)
+	}
+
+	metadata := map[string]interface{}{"transaction_id": transaction.ID}
+	for key, value := range req.Metadata {
+		metadata[key] = value
+	}
+	if transaction.Checkout != nil && transaction.Checkout.URL != nil {
+		metadata["checkout_url"] = *transaction.Checkout.URL
+	}
+
+	return &Subscription{
+		CustomerID: req.CustomerID,
+		PlanID:     req.PlanID,
+		Status:     "incomplete",
+		Metadata:   metadata,
+		CreatedAt:  transaction.CreatedAt,
+		UpdatedAt:  transaction.UpdatedAt,
+		Provider:   "paddle",
+	}, nil
+}
+
+func (g *PaddleGateway) GetSubscription(ctx context.Context, subscriptionID string) (*Subscription, error) {
+	var subscription paddleSubscription
+	if err := g.do(ctx, http.MethodGet, "/subscriptions/"+url.PathEscape(subscriptionID), nil, nil, &subscription, nil); err != nil {
+		return nil, g.paymentError("subscription_retrieval_failed", "failed to retrieve subscription", err)
+	}
+
+	return convertPaddleSubscription(&subscription), nil
+}
+
+// UpdateSubscription changes the plan, prorating immediately, and the custom data
+func (g *PaddleGateway) UpdateSubscription(ctx context.Context, subscriptionID string, req UpdateSubscriptionRequest) (*Subscription, error) {
+	body := map[string]interface{}{}
+	if req.PlanID != "" {
+		body["items"] = []map[string]interface{}{{"price_id": req.PlanID, "quantity": 1}}
+		body["proration_billing_mode"] = "prorated_immediately"
+	}
+	if req.Metadata != nil {
+		body["custom_data"] = req.Metadata
+	}
+
+	var subscription paddleSubscription
+	if err := g.do(ctx, http.MethodPatch, "/subscriptions/"+url.PathEscape(subscriptionID), nil, body, &subscription, nil); err != nil {
+		return nil, g.paymentError("subscription_update_failed", "failed to update subscription", err)
+	}
+
+	return convertPaddleSubscription(&subscription), nil
+}
+
+func (g *PaddleGateway) CancelSubscription(ctx context.Context, subscriptionID string, req CancelSubscriptionRequest) (*Subscription, error) {
+	effectiveFrom := "immediately"
+	if req.AtPeriodEnd {
+		effectiveFrom = "next_billing_period"
+	}
+	body := map[string]interface{}{"effective_from": effectiveFrom}
+
+	var subscription paddleSubscription
+	path := "/subscriptions/" + url.PathEscape(subscriptionID) + "/cancel"
+	if err := g.do(ctx, http.MethodPost, path, nil, body, &subscription, nil); err != nil {
+		return nil, g.paymentError("subscription_cancellation_failed", "failed to cancel subscription", err)
+	}
+
+	return convertPaddleSubscription(&subscription), nil
+}
+
+func (g *PaddleGateway) ListSubscriptions(ctx context.Context, req ListSubscriptionsRequest) (*SubscriptionList, error) {
+	query := g.pageQuery(req.Limit, req.Cursor)
+	if req.CustomerID != "" {
+		query.Set("customer_id", req.CustomerID)
+	}
+	if req.Status != "" {
+		query.Set("status", req.Status)
+	}
+
+	var paddleSubscriptions []paddleSubscription
+	var pagination paddlePagination
+	if err := g.do(ctx, http.MethodGet, "/subscriptions", query, nil, &paddleSubscriptions, &pagination); err != nil {
+		return nil, g.paymentError("subscription_list_failed", "failed to list subscriptions", err)
+	}
+
+	subscriptions := make([]*Subscription, len(paddleSubscriptions))
+	for i := range paddleSubscriptions {
+		subscriptions[i] = convertPaddleSubscription(&paddleSubscriptions[i])
+	}
+
+	list := &SubscriptionList{
+		Subscriptions: subscriptions,
+		Total:         pagination.EstimatedTotal,
+		HasMore:       pagination.HasMore,
+	}
+	if list.HasMore && len(subscriptions) > 0 {
+		list.NextCursor = subscriptions[len(subscriptions)-1].ID
+	}
+
+	return list, nil
+}
+
+// Paddle API helpers
+
+// paddleAPIError is the error object Paddle returns with non-2xx responses
+type paddleAPIError struct {
+	Status int
+	Type   string `json:"type"`
+	Code   string `json:"code"`
+	Detail string `json:"detail"`
+}
+
+// HTTPStatus returns the status of the response Paddle returned the error with
+func (e *paddleAPIError) HTTPStatus() int {
+	return e.Status
+}
+
+func (e *paddleAPIError) Error() string {
+	return fmt.Sprintf("paddle %s (%d): %s", e.Code, e.Status, e.Detail)
+}
+
+// do sends a request to the Paddle API and decodes the data and pagination
+// of the response envelope into out and pagination when they are not nil
+func (g *PaddleGateway) do(ctx context.Context, method, path string, query url.Values, body, out interface{}, pagination *paddlePagination) error {
+	endpoint := g.baseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+g.apiKey)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var envelope struct {
+		Data  json.RawMessage `json:"data"`
+		Error *paddleAPIError `json:"error"`
+		Meta  struct {
+			Pagination *paddlePagination `json:"pagination"`
+		} `json:"meta"`
+	}
+	if resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil && resp.StatusCode < 300 {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+
+	if resp.StatusCode >= 300 {
+		if envelope.Error == nil {
+			envelope.Error = &paddleAPIError{Code: "http_error", Detail: http.StatusText(resp.StatusCode)}
+		}
+		envelope.Error.Status = resp.StatusCode
+		return envelope.Error
+	}
+
+	if out != nil && len(envelope.Data) > 0 {
+		if err := json.Unmarshal(envelope.Data, out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	if pagination != nil && envelope.Meta.Pagination != nil {
+		*pagination = *envelope.Meta.Pagination
+	}
+
+	return nil
+}
+
+// pageQuery returns a query requesting one page of up to limit results
+// pageQuery selects a page of a Paddle list. Paddle cursors are the ID of
+// the last entity on the previous page.
+func (g *PaddleGateway) pageQuery(limit int, cursor string) url.Values {
+	query := url.Values{}
+	if limit > 0 {
+		query.Set("per_page", strconv.Itoa(limit))
+	}
+	if cursor != "" {
+		query.Set("after", cursor)
+	}
+	return query
+}
+
+func (g *PaddleGateway) getTransaction(ctx context.Context, transactionID string) (*paddleTransaction, error) {
+	var transaction paddleTransaction
+	if err := g.do(ctx, http.MethodGet, "/transactions/"+url.PathEscape(transactionID), nil, nil, &transaction, nil); err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// createAddress adds an address to a customer
+func (g *PaddleGateway) createAddress(ctx context.Context, customerID string, address *Address) (*Address, error) {
+	body := paddleAddress{
+		CountryCode: strings.ToUpper(address.Country),
+		PostalCode:  optionalString(address.PostalCode),
+		Region:      optionalString(address.State),
+		City:        optionalString(address.City),
+		FirstLine:   optionalString(address.Line1),
+		SecondLine:  optionalString(address.Line2),
+	}
+
+	var created paddleAddress
+	if err := g.do(ctx, http.MethodPost, "/customers/"+url.PathEscape(customerID)+"/addresses", nil, body, &created, nil); err != nil {
+		return nil, err
+	}
+
+	return convertPaddleAddress(&created), nil
+}
+
+// activeAddress returns a customer's most recent active address, or nil
+func (g *PaddleGateway) activeAddress(ctx context.Context, customerID string) (*Address, error) {
+	query := url.Values{"status": {"active"}, "per_page": {"1"}}
+
+	var addresses []paddleAddress
+	if err := g.do(ctx, http.MethodGet, "/customers/"+url.PathEscape(customerID)+"/addresses", query, nil, &addresses, nil); err != nil {
+		return nil, err
+	}
+	if len(addresses) == 0 {
+		return nil, nil
+	}
+
+	return convertPaddleAddress(&addresses[0]), nil
+}
+
+// paddleRefundItems builds the adjustment items refunding amount from a
+// transaction; an amount of zero or at least the total refunds every item
+func paddleRefundItems(transaction *paddleTransaction, amount int64) ([]map[string]interface{}, error) {
+	total := parsePaddleAmount(transaction.Details.Totals.Total)
+	items := make([]map[string]interface{}, 0, len(transaction.Details.LineItems))
+
+	if amount <= 0 || amount >= total {
+		for _, item := range transaction.Details.LineItems {
+			items = append(items, map[string]interface{}{"item_id": item.ID, "type": "full"})
+		}
+		return items, nil
+	}
+
+	remaining := amount
+	for _, item := range transaction.Details.LineItems {
+		if remaining == 0 {
+			break
+		}
+		itemTotal := parsePaddleAmount(item.Totals.Total)
+		if itemTotal <= 0 {
+			continue
+		}
+		if remaining >= itemTotal {
+			items = append(items, map[string]interface{}{"item_id": item.ID, "type": "full"})
+			remaining -= itemTotal
+			continue
+		}
+		items = append(items, map[string]interface{}{"item_id": item.ID, "type": "partial", "amount": strconv.FormatInt(remaining, 10)})
+		remaining = 0
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("transaction %s has no refundable items", transaction.ID)
+	}
+
+	return items, nil
+}
+
+// paddleTransactionStatuses maps a common charge status to Paddle transaction statuses
+func paddleTransactionStatuses(status string) []string {
+	switch status {
+	case "succeeded":
+		return []string{"paid", "completed"}
+	case "pending":
+		return []string{"draft", "ready", "billed"}
+	case "failed":
+		return []string{"past_due"}
+	default:
+		return []string{status}
+	}
+}
+
+// parsePaddleAmount parses an amount Paddle sends as a string of minor units
+func parsePaddleAmount(amount string) int64 {
+	value, _ := strconv.ParseInt(amount, 10, 64)
+	return value
+}
+
+func (g *PaddleGateway) notSupported(message string) error {
+	return &PaymentError{Code: "not_supported", Message: message, Provider: "paddle"}
+}
+
+func (g *PaddleGateway) paymentError(code, message string, err error) error {
+	return &PaymentError{
+		Code:     code,
+		Message:  fmt.Sprintf("%s: %v", message, err),
+		Provider: "paddle",
+	}
+}
+
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// Conversion helper methods
+
+func convertPaddleCustomer(pc *paddleCustomer) *Customer {
+	return &Customer{
+		ID:         pc.ID,
+		Email:      pc.Email,
+		Name:       stringValue(pc.Name),
+		Metadata:   pc.CustomData,
+		CreatedAt:  pc.CreatedAt,
+		UpdatedAt:  pc.UpdatedAt,
+		ProviderID: pc.ID,
+		Provider:   "paddle",
+	}
+}
+
+func convertPaddleAddress(pa *paddleAddress) *Address {
+	return &Address{
+		Line1:      stringValue(pa.FirstLine),
+		Line2:      stringValue(pa.SecondLine),
+		City:       stringValue(pa.City),
+		State:      stringValue(pa.Region),
+		PostalCode: stringValue(pa.PostalCode),
+		Country:    pa.CountryCode,
+	}
+}
+
+func convertPaddlePaymentMethod(ppm *paddlePaymentMethod) *PaymentMethod {
+	pm := &PaymentMethod{
+		ID:         ppm.ID,
+		CustomerID: ppm.CustomerID,
+		Type:       ppm.Type,
+		CreatedAt:  ppm.SavedAt,
+		ProviderID: ppm.ID,
+		Provider:   "paddle",
+	}
+
+	if ppm.Card != nil {
+		pm.Card = &Card{
+			Brand:    ppm.Card.Type,
+			Last4:    ppm.Card.Last4,
+			ExpMonth: ppm.Card.ExpiryMonth,
+			ExpYear:  ppm.Card.ExpiryYear,
+		}
+	}
+
+	return pm
+}
+
+// convertPaddleTransaction converts a transaction to a charge. Paid and
+// completed transactions have succeeded; past due ones failed to collect.
+func convertPaddleTransaction(pt *paddleTransaction) *Charge {
+	status := "pending"
+	switch pt.Status {
+	case "paid", "completed":
+		status = "succeeded"
+	case "past_due":
+		status = "failed"
+	case "canceled":
+		status = "canceled"
+	}
+
+	c := &Charge{
+		ID:         pt.ID,
+		Amount:     parsePaddleAmount(pt.Details.Totals.Total),
+		Currency:   strings.ToLower(pt.CurrencyCode),
+		CustomerID: stringValue(pt.CustomerID),
+		Status:     status,
+		Metadata:   pt.CustomData,
+		CreatedAt:  pt.CreatedAt,
+		UpdatedAt:  pt.UpdatedAt,
+		ProviderID: pt.ID,
+		Provider:   "paddle",
+	}
+
+	for _, payment := range pt.Payments {
+		if payment.PaymentMethodID != nil {
+			c.PaymentMethodID = *payment.PaymentMethodID
+			break
+		}
+	}
+
+	if pt.Checkout != nil && pt.Checkout.URL != nil && status == "pending" {
+		metadata := map[string]interface{}{"checkout_url": *pt.Checkout.URL}
+		for key, value := range pt.CustomData {
+			metadata[key] = value
+		}
+		c.Metadata = metadata
+	}
+
+	return c
+}
+
+// convertPaddleAdjustment converts a refund adjustment. Paddle reviews
+// refunds, so they stay pending until approved.
+func convertPaddleAdjustment(pa *paddleAdjustment) *Refund {
+	status := "pending"
+	switch pa.Status {
+	case "approved":
+		status = "succeeded"
+	case "rejected":
+		status = "failed"
+	case "reversed":
+		status = "canceled"
+	}
+
+	r := &Refund{
+		ID:         pa.ID,
+		ChargeID:   pa.TransactionID,
+		Amount:     parsePaddleAmount(pa.Totals.Total),
+		Currency:   strings.ToLower(pa.CurrencyCode),
+		Reason:     pa.Reason,
+		Status:     status,
+		CreatedAt:  pa.CreatedAt,
+		UpdatedAt:  pa.CreatedAt,
+		ProviderID: pa.ID,
+		Provider:   "paddle",
+	}
+	if pa.UpdatedAt != nil {
+		r.UpdatedAt = *pa.UpdatedAt
+	}
+
+	return r
+}
+
+func convertPaddleSubscription(ps *paddleSubscription) *Subscription {
+	s := &Subscription{
+		ID:         ps.ID,
+		CustomerID: ps.CustomerID,
+		Status:     ps.Status,
+		Metadata:   ps.CustomData,
+		CreatedAt:  ps.CreatedAt,
+		UpdatedAt:  ps.UpdatedAt,
+		ProviderID: ps.ID,
+		Provider:   "paddle",
+	}
+
+	if len(ps.Items) > 0 {
+		s.PlanID = ps.Items[0].Price.ID
+	}
+	if ps.CurrentBillingPeriod != nil {
+		s.CurrentPeriodStart = ps.CurrentBillingPeriod.StartsAt
+		s.CurrentPeriodEnd = ps.CurrentBillingPeriod.EndsAt
+	}
+
+	return s
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PaddleSignatureTolerance is how old a signed Paddle notification may be
+const PaddleSignatureTolerance = 5 * time.Minute
+
+// PaddleEvent is a Paddle Billing webhook notification
+type PaddleEvent struct {
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	OccurredAt     time.Time       `json:"occurred_at"`
+	NotificationID string          `json:"notification_id"`
+	Data           json.RawMessage `json:"data"`
+}
+
+// PaddleEventHandler handles one type of Paddle event
+type PaddleEventHandler func(ctx context.Context, event PaddleEvent) error
+
+// On registers a handler for a Paddle event type, e.g. transaction.completed
+func (g *PaddleGateway) On(eventType string, handler PaddleEventHandler) {
+	g.handlers[eventType] = append(g.handlers[eventType], handler)
+}
+
+// ConstructEvent verifies the Paddle-Signature header and parses the event payload
+func (g *PaddleGateway) ConstructEvent(payload []byte, signature string) (PaddleEvent, error) {
+	if g.webhookSecret == "" {
+		return PaddleEvent{}, fmt.Errorf("webhook secret is not configured")
+	}
+
+	if err := verifyPaddleSignature(payload, signature, g.webhookSecret, time.Now()); err != nil {
+		return PaddleEvent{}, fmt.Errorf("failed to verify webhook signature: %w", err)
+	}
+
+	var event PaddleEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return PaddleEvent{}, fmt.Errorf("failed to parse event: %w", err)
+	}
+
+	return event, nil
+}
+
+// HandleWebhook verifies a notification and runs all handlers registered for its type
+func (g *PaddleGateway) HandleWebhook(ctx context.Context, payload []byte, signature string) (PaddleEvent, error) {
+	event, err := g.ConstructEvent(payload, signature)
+	if err != nil {
+		return PaddleEvent{}, err
+	}
+
+	for _, handler := range g.handlers[event.EventType] {
+		if err := handler(ctx, event); err != nil {
+			return event, fmt.Errorf("failed to handle %s event %s: %w", event.EventType, event.EventID, err)
+		}
+	}
+
+	return event, nil
+}
+
+// Customer returns the customer in a customer.* event
+func (e PaddleEvent) Customer() (*Customer, error) {
+	var customer paddleCustomer
+	if err := json.Unmarshal(e.Data, &customer); err != nil {
+		return nil, fmt.Errorf("failed to parse customer: %w", err)
+	}
+
+	return convertPaddleCustomer(&customer), nil
+}
+
+// Charge returns the transaction in a transaction.* event as a charge
+func (e PaddleEvent) Charge() (*Charge, error) {
+	var transaction paddleTransaction
+	if err := json.Unmarshal(e.Data, &transaction); err != nil {
+		return nil, fmt.Errorf("failed to parse transaction: %w", err)
+	}
+
+	return convertPaddleTransaction(&transaction), nil
+}
+
+// Refund returns the adjustment in an adjustment.* event as a refund
+func (e PaddleEvent) Refund() (*Refund, error) {
+	var adjustment paddleAdjustment
+	if err := json.Unmarshal(e.Data, &adjustment); err != nil {
+		return nil, fmt.Errorf("failed to parse adjustment: %w", err)
+	}
+
+	return convertPaddleAdjustment(&adjustment), nil
+}
+
+// Subscription returns the subscription in a subscription.* event
+func (e PaddleEvent) Subscription() (*Subscription, error) {
+	var subscription paddleSubscription
+	if err := json.Unmarshal(e.Data, &subscription); err != nil {
+		return nil, fmt.Errorf("failed to parse subscription: %w", err)
+	}
+
+	return convertPaddleSubscription(&subscription), nil
+}
+
+// verifyPaddleSignature checks a "ts=<unix>;h1=<hex>" header, where h1 is
+// the HMAC-SHA256 of "<ts>:<payload>". Paddle may send several h1 values
+// while a secret is rotated; any match is accepted.
+func verifyPaddleSignature(payload []byte, header, secret string, now time.Time) error {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "ts":
+			timestamp = value
+		case "h1":
+			signatures = append(signatures, value)
+		}
+	}
+	if timestamp == "" || len(signatures) == 0 {
+		return fmt.Errorf("malformed signature header")
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("malformed signature timestamp: %w", err)
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > PaddleSignatureTolerance || age < -PaddleSignatureTolerance {
+		return fmt.Errorf("signature timestamp outside tolerance")
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + ":"))
+	mac.Write(payload)
+	expected := mac.Sum(nil)
+
+	for _, signature := range signatures {
+		decoded, err := hex.DecodeString(signature)
+		if err == nil && hmac.Equal(decoded, expected) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("no signature matches the payload")
+}
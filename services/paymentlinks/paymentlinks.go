@@ -0,0 +1,102 @@
+package paymentlinks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"time"
+
+	"apis/payments/services/stripe"
+)
+
+// Event types emitted for payment links
+const (
+	EventConverted = "payments.payment_link.converted"
+	EventExpired   = "payments.payment_link.expired"
+)
+
+// ErrExpiryInPast is returned when creating a payment link that has already expired
+var ErrExpiryInPast = errors.New("expires_at must be in the future")
+
+// PaymentLink is a shareable checkout URL for a fixed amount of one product,
+// with the conversions made through it
+type PaymentLink struct {
+	ID              string            `json:"id"`
+	URL             string            `json:"url"`
+	Amount          int64             `json:"amount"`
+	Currency        string            `json:"currency"`
+	ProductName     string            `json:"product_name"`
+	PriceID         string            `json:"price_id"`
+	Active          bool              `json:"active"`
+	ExpiresAt       *time.Time        `json:"expires_at,omitempty"`
+	MaxUses         int64             `json:"max_uses,omitempty"` // 0 means unlimited
+	Conversions     int64             `json:"conversions"`
+	AmountCollected int64             `json:"amount_collected"`
+	Metadata        map[string]string `json:"metadata,omitempty"`
+	DeactivatedAt   *time.Time        `json:"deactivated_at,omitempty"`
+	CreatedAt       time.Time         `json:"created_at"`
+}
+
+// CreateRequest creates a payment link
+type CreateRequest struct {
+	Amount      int64             `json:"amount" validate:"required,gt=0"`
+	Currency    string            `json:"currency" validate:"required,len=3"`
+	ProductName string            `json:"product_name" validate:"required,max=250"`
+	ExpiresAt   *time.Time        `json:"expires_at,omitempty"`
+	MaxUses     int64             `json:"max_uses,omitempty" validate:"gte=0"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+// Conversion is a paid checkout completed through a payment link
+type Conversion struct {
+	SessionID       string    `json:"session_id"`
+	PaymentLinkID   string    `json:"payment_link_id"`
+	AmountTotal     int64     `json:"amount_total"`
+	Currency        string    `json:"currency"`
+	CustomerID      string    `json:"customer_id,omitempty"`
+	PaymentIntentID string    `json:"payment_intent_id,omitempty"`
+	CompletedAt     time.Time `json:"completed_at"`
+}
+
+// Filter narrows a payment link listing
+type Filter struct {
+	ActiveOnly bool
+	Limit      int
+}
+
+// Config controls payment link expiry
+type Config struct {
+	// ExpiryInterval is how often links past their expiry are deactivated
+	ExpiryInterval time.Duration
+}
+
+// LoadConfig loads the payment link configuration from environment variables
+func LoadConfig() *Config {
+	config := &Config{ExpiryInterval: 5 * time.Minute}
+
+	if minutes, err := strconv.Atoi(os.Getenv("PAYMENT_LINK_EXPIRY_INTERVAL_MINUTES")); err == nil && minutes > 0 {
+		config.ExpiryInterval = time.Duration(minutes) * time.Minute
+	}
+
+	return config
+}
+
+// Store persists payment links and their conversions. Conversions are
+// recorded once per checkout session.
+type Store interface {
+	CreatePaymentLink(ctx context.Context, link *PaymentLink) (*PaymentLink, error)
+	GetPaymentLink(ctx context.Context, linkID string) (*PaymentLink, error)
+	ListPaymentLinks(ctx context.Context, filter Filter) ([]*PaymentLink, error)
+	DeactivatePaymentLink(ctx context.Context, linkID string, at time.Time) (*PaymentLink, error)
+	ListExpiredPaymentLinks(ctx context.Context, now time.Time) ([]*PaymentLink, error)
+	InsertPaymentLinkConversion(ctx context.Context, conversion *Conversion) (bool, error)
+	AddPaymentLinkConversion(ctx context.Context, linkID string, amount int64) (*PaymentLink, error)
+	ListPaymentLinkConversions(ctx context.Context, linkID string, limit int) ([]*Conversion, error)
+}
+
+// Provider creates and deactivates payment links at the payment provider
+type Provider interface {
+	CreatePaymentLink(ctx context.Context, request *stripe.PaymentLinkRequest) (*stripe.PaymentLink, error)
+	DeactivatePaymentLink(ctx context.Context, linkID string) (*stripe.PaymentLink, error)
+}
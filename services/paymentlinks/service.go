@@ -0,0 +1,249 @@
+package paymentlinks
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"apis/payments/services/events"
+	"apis/payments/services/stripe"
+
+	"github.com/go-playground/validator/v10"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 500
+)
+
+// Service creates payment links, deactivates them on request or once they
+// expire, and tracks the conversions made through them
+type Service struct {
+	store     Store
+	provider  Provider
+	emitter   *events.Emitter
+	config    *Config
+	validator *validator.Validate
+	tracer    trace.Tracer
+}
+
+// NewService creates a new payment link service
+func NewService(store Store, provider Provider, emitter *events.Emitter, config *Config) *Service {
+	if config == nil {
+		config = LoadConfig()
+	}
+
+	return &Service{
+		store:     store,
+		provider:  provider,
+		emitter:   emitter,
+		config:    config,
+		validator: validator.New(),
+		tracer:    otel.Tracer("payments.paymentlinks"),
+	}
+}
+
+// Create creates a payment link at the provider and stores it. The provider
+// enforces MaxUses; ExpiresAt is enforced by deactivating expired links.
+func (s *Service) Create(ctx context.Context, request *CreateRequest) (*PaymentLink, error) {
+	ctx, span := s.tracer.Start(ctx, "Create")
+	defer span.End()
+
+	if err := s.validator.Struct(request); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		return nil, ErrExpiryInPast
+	}
+
+	created, err := s.provider.CreatePaymentLink(ctx, &stripe.PaymentLinkRequest{
+		Amount:      request.Amount,
+		Currency:    request.Currency,
+		ProductName: request.ProductName,
+		MaxUses:     request.MaxUses,
+		Metadata:    request.Metadata,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.store.CreatePaymentLink(ctx, &PaymentLink{
+		ID:          created.ID,
+		URL:         created.URL,
+		Amount:      request.Amount,
+		Currency:    request.Currency,
+		ProductName: request.ProductName,
+		PriceID:     created.PriceID,
+		Active:      true,
+		ExpiresAt:   request.ExpiresAt,
+		MaxUses:     request.MaxUses,
+		Metadata:    request.Metadata,
+	})
+}
+
+// Get returns a payment link with its conversion totals
+func (s *Service) Get(ctx context.Context, linkID string) (*PaymentLink, error) {
+	ctx, span := s.tracer.Start(ctx, "Get")
+	defer span.End()
+
+	return s.store.GetPaymentLink(ctx, linkID)
+}
+
+// List returns payment links, newest first
+func (s *Service) List(ctx context.Context, filter Filter) ([]*PaymentLink, error) {
+	ctx, span := s.tracer.Start(ctx, "List")
+	defer span.End()
+
+	filter.Limit = listLimit(filter.Limit)
+	return s.store.ListPaymentLinks(ctx, filter)
+}
+
+// Conversions returns the conversions made through a payment link, newest first
+func (s *Service) Conversions(ctx context.Context, linkID string, limit int) ([]*Conversion, error) {
+	ctx, span := s.tracer.Start(ctx, "Conversions")
+	defer span.End()
+
+	if _, err := s.store.GetPaymentLink(ctx, linkID); err != nil {
+		return nil, err
+	}
+
+	return s.store.ListPaymentLinkConversions(ctx, linkID, listLimit(limit))
+}
+
+// Deactivate deactivates a payment link. Deactivating an inactive link
+// returns it unchanged.
+func (s *Service) Deactivate(ctx context.Context, linkID string) (*PaymentLink, error) {
+	ctx, span := s.tracer.Start(ctx, "Deactivate")
+	defer span.End()
+
+	link, err := s.store.GetPaymentLink(ctx, linkID)
+	if err != nil {
+		return nil, err
+	}
+	if !link.Active {
+		return link, nil
+	}
+
+	return s.deactivate(ctx, linkID)
+}
+
+// ExpireDue deactivates every active link whose expiry has passed at now,
+// returning how many were deactivated
+func (s *Service) ExpireDue(ctx context.Context, now time.Time) (int, error) {
+	ctx, span := s.tracer.Start(ctx, "ExpireDue")
+	defer span.End()
+
+	expired, err := s.store.ListExpiredPaymentLinks(ctx, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list expired payment links: %w", err)
+	}
+
+	count := 0
+	for _, link := range expired {
+		deactivated, err := s.deactivate(ctx, link.ID)
+		if err != nil {
+			log.Printf("Failed to expire payment link %s: %v", link.ID, err)
+			continue
+		}
+		count++
+
+		if err := s.emitter.Emit(ctx, "payment-links", EventExpired, link.ID, deactivated); err != nil {
+			log.Printf("Failed to emit %s for payment link %s: %v", EventExpired, link.ID, err)
+		}
+	}
+
+	return count, nil
+}
+
+// RecordConversion records a paid checkout through a payment link. Each
+// checkout session is counted once, so redelivered webhooks are ignored.
+// Checkouts through links created elsewhere are ignored too.
+func (s *Service) RecordConversion(ctx context.Context, conversion *Conversion) error {
+	ctx, span := s.tracer.Start(ctx, "RecordConversion")
+	defer span.End()
+
+	if _, err := s.store.GetPaymentLink(ctx, conversion.PaymentLinkID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	inserted, err := s.store.InsertPaymentLinkConversion(ctx, conversion)
+	if err != nil {
+		return fmt.Errorf("failed to record payment link conversion: %w", err)
+	}
+	if !inserted {
+		return nil
+	}
+
+	link, err := s.store.AddPaymentLinkConversion(ctx, conversion.PaymentLinkID, conversion.AmountTotal)
+	if err != nil {
+		return fmt.Errorf("failed to count payment link conversion: %w", err)
+	}
+
+	// The provider deactivates the link itself once MaxUses checkouts complete
+	if link.Active && link.MaxUses > 0 && link.Conversions >= link.MaxUses {
+		if _, err := s.store.DeactivatePaymentLink(ctx, link.ID, conversion.CompletedAt); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to deactivate used up payment link: %w", err)
+		}
+	}
+
+	return s.emitter.Emit(ctx, "payment-links", EventConverted, conversion.PaymentLinkID, conversion)
+}
+
+// Start deactivates expired payment links every ExpiryInterval until the
+// returned stop function is called
+func (s *Service) Start() (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(s.config.ExpiryInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := s.ExpireDue(context.Background(), time.Now()); err != nil {
+					log.Printf("Payment link expiry run failed: %v", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// deactivate deactivates a link at the provider, then locally
+func (s *Service) deactivate(ctx context.Context, linkID string) (*PaymentLink, error) {
+	if _, err := s.provider.DeactivatePaymentLink(ctx, linkID); err != nil {
+		return nil, err
+	}
+
+	link, err := s.store.DeactivatePaymentLink(ctx, linkID, time.Now())
+	if errors.Is(err, sql.ErrNoRows) {
+		// Deactivated concurrently
+		return s.store.GetPaymentLink(ctx, linkID)
+	}
+	return link, err
+}
+
+// listLimit returns the page size for a listing
+func listLimit(limit int) int {
+	if limit <= 0 {
+		return defaultListLimit
+	}
+	return min(limit, maxListLimit)
+}
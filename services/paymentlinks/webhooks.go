@@ -0,0 +1,47 @@
+package paymentlinks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"apis/payments/services/stripe"
+
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// RegisterWebhookHandlers records conversions from completed checkouts.
+// Checkouts with delayed payment methods count once the payment succeeds.
+func (s *Service) RegisterWebhookHandlers(webhooks *stripe.WebhookService) {
+	for _, eventType := range []stripego.EventType{
+		stripego.EventTypeCheckoutSessionCompleted,
+		stripego.EventTypeCheckoutSessionAsyncPaymentSucceeded,
+	} {
+		webhooks.On(eventType, func(ctx context.Context, event stripego.Event) error {
+			var session stripego.CheckoutSession
+			if err := json.Unmarshal(event.Data.Raw, &session); err != nil {
+				return fmt.Errorf("failed to parse checkout session: %w", err)
+			}
+			if session.PaymentLink == nil || session.PaymentStatus != stripego.CheckoutSessionPaymentStatusPaid {
+				return nil
+			}
+
+			conversion := &Conversion{
+				SessionID:     session.ID,
+				PaymentLinkID: session.PaymentLink.ID,
+				AmountTotal:   session.AmountTotal,
+				Currency:      string(session.Currency),
+				CompletedAt:   time.Unix(event.Created, 0).UTC(),
+			}
+			if session.Customer != nil {
+				conversion.CustomerID = session.Customer.ID
+			}
+			if session.PaymentIntent != nil {
+				conversion.PaymentIntentID = session.PaymentIntent.ID
+			}
+
+			return s.RecordConversion(ctx, conversion)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package paymentmethods
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"apis/payments/services/stripe"
+
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// MaxBackups is the most backup payment methods a customer may have
+const MaxBackups = 5
+
+var (
+	// ErrInvalidPreference is returned when saving an invalid payment method order
+	ErrInvalidPreference = errors.New("invalid payment method preference")
+	// ErrNotAttached is returned when preferring a payment method that is not
+	// attached to the customer
+	ErrNotAttached = errors.New("payment method is not attached to the customer")
+)
+
+// Preference is the order a customer's payment methods are charged in: the
+// default first, then each backup when the one before it is declined
+type Preference struct {
+	TenantID             string    `json:"tenant_id"`
+	CustomerID           string    `json:"customer_id"`
+	DefaultPaymentMethod string    `json:"default_payment_method,omitempty"`
+	BackupPaymentMethods []string  `json:"backup_payment_methods"`
+	CreatedAt            time.Time `json:"created_at,omitempty"`
+	UpdatedAt            time.Time `json:"updated_at,omitempty"`
+}
+
+// Provider holds customers' payment methods and charges their default for
+// invoices and subscriptions
+type Provider interface {
+	GetCustomer(ctx context.Context, customerID string) (*stripe.Customer, error)
+	GetPaymentMethod(ctx context.Context, paymentMethodID string) (*stripe.PaymentMethod, error)
+	SetDefaultPaymentMethod(ctx context.Context, customerID, paymentMethodID string) error
+}
+
+// Charger creates a charge with one payment method
+type Charger interface {
+	CreateCharge(ctx context.Context, request *stripe.ChargeRequest) (*stripe.Charge, error)
+}
+
+// InvoicePayer pays an open invoice with a payment method
+type InvoicePayer interface {
+	PayInvoice(ctx context.Context, invoiceID, paymentMethodID string) (*stripe.Invoice, error)
+}
+
+// SubscriptionGetter retrieves a subscription from the provider
+type SubscriptionGetter interface {
+	GetSubscription(ctx context.Context, subscriptionID string) (*stripe.Subscription, error)
+}
+
+// noFallbackDeclineCodes are declines another of the customer's payment
+// methods should not be tried after: the customer or merchant is suspected
+// of fraud rather than the card being unusable
+var noFallbackDeclineCodes = map[string]bool{
+	string(stripego.DeclineCodeFraudulent):        true,
+	string(stripego.DeclineCodeMerchantBlacklist): true,
+	string(stripego.DeclineCodeStolenCard):        true,
+	string(stripego.DeclineCodeLostCard):          true,
+	string(stripego.DeclineCodePickupCard):        true,
+}
+
+// CanFallBack reports whether a failed charge was declined in a way the
+// customer's next payment method may not be
+func CanFallBack(err error) bool {
+	var stripeErr *stripego.Error
+	if errors.As(err, &stripeErr) {
+		return stripeErr.Type == stripego.ErrorTypeCard && !noFallbackDeclineCodes[string(stripeErr.DeclineCode)]
+	}
+	var declineErr interface{ GetDeclineCode() string }
+	if errors.As(err, &declineErr) && declineErr.GetDeclineCode() != "" {
+		return !noFallbackDeclineCodes[declineErr.GetDeclineCode()]
+	}
+	return false
+}
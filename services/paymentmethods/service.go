@@ -0,0 +1,290 @@
+package paymentmethods
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"apis/payments/services/stripe"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Store persists payment method preferences. Customers without a
+// preference return sql.ErrNoRows.
+type Store interface {
+	GetPaymentMethodPreference(ctx context.Context, tenantID, customerID string) (*Preference, error)
+	UpsertPaymentMethodPreference(ctx context.Context, preference *Preference) (*Preference, error)
+}
+
+// Service keeps the order customers' payment methods are charged in and
+// retries declined charges and subscriptions with their backups
+type Service struct {
+	store         Store
+	provider      Provider
+	charges       Charger
+	invoices      InvoicePayer
+	subscriptions SubscriptionGetter
+	tracer        trace.Tracer
+}
+
+// NewService creates a new payment method preference service
+func NewService(store Store, provider Provider, charges Charger, invoices InvoicePayer, subscriptions SubscriptionGetter) *Service {
+	return &Service{
+		store:         store,
+		provider:      provider,
+		charges:       charges,
+		invoices:      invoices,
+		subscriptions: subscriptions,
+		tracer:        otel.Tracer("payments.paymentmethods"),
+	}
+}
+
+// Get returns a customer's payment method preference. Customers that have
+// saved none are charged the provider's default with no backups.
+func (s *Service) Get(ctx context.Context, tenantID, customerID string) (*Preference, error) {
+	ctx, span := s.tracer.Start(ctx, "Get")
+	defer span.End()
+
+	preference, err := s.store.GetPaymentMethodPreference(ctx, tenantID, customerID)
+	if err == nil {
+		return preference, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	customer, err := s.provider.GetCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	return &Preference{
+		TenantID:             tenantID,
+		CustomerID:           customerID,
+		DefaultPaymentMethod: customer.DefaultPaymentMethod,
+		BackupPaymentMethods: []string{},
+	}, nil
+}
+
+// SetDefault makes a payment method the customer's default, at the
+// provider and locally. The previous default becomes the first backup.
+func (s *Service) SetDefault(ctx context.Context, tenantID, customerID, paymentMethodID string) (*Preference, error) {
+	ctx, span := s.tracer.Start(ctx, "SetDefault")
+	defer span.End()
+	span.SetAttributes(attribute.String("customer_id", customerID))
+
+	if paymentMethodID == "" {
+		return nil, fmt.Errorf("%w: payment_method is required", ErrInvalidPreference)
+	}
+	if err := s.checkAttached(ctx, customerID, paymentMethodID); err != nil {
+		return nil, err
+	}
+
+	preference, err := s.Get(ctx, tenantID, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	backups := []string{}
+	if previous := preference.DefaultPaymentMethod; previous != "" && previous != paymentMethodID {
+		backups = append(backups, previous)
+	}
+	for _, backup := range preference.BackupPaymentMethods {
+		if backup != paymentMethodID && backup != preference.DefaultPaymentMethod && len(backups) < MaxBackups {
+			backups = append(backups, backup)
+		}
+	}
+
+	// The provider charges the default for invoices it collects itself, so
+	// it is synced before the preference is saved
+	if err := s.provider.SetDefaultPaymentMethod(ctx, customerID, paymentMethodID); err != nil {
+		return nil, err
+	}
+
+	preference.DefaultPaymentMethod = paymentMethodID
+	preference.BackupPaymentMethods = backups
+	return s.store.UpsertPaymentMethodPreference(ctx, preference)
+}
+
+// SetBackups replaces the payment methods tried, in order, when the
+// customer's default is declined
+func (s *Service) SetBackups(ctx context.Context, tenantID, customerID string, backups []string) (*Preference, error) {
+	ctx, span := s.tracer.Start(ctx, "SetBackups")
+	defer span.End()
+	span.SetAttributes(attribute.String("customer_id", customerID))
+
+	if len(backups) > MaxBackups {
+		return nil, fmt.Errorf("%w: at most %d backup payment methods are allowed", ErrInvalidPreference, MaxBackups)
+	}
+
+	preference, err := s.Get(ctx, tenantID, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if preference.DefaultPaymentMethod == "" && len(backups) > 0 {
+		return nil, fmt.Errorf("%w: a default payment method must be set before backups", ErrInvalidPreference)
+	}
+
+	seen := make(map[string]bool, len(backups))
+	for _, backup := range backups {
+		switch {
+		case backup == "":
+			return nil, fmt.Errorf("%w: backup payment methods cannot be empty", ErrInvalidPreference)
+		case backup == preference.DefaultPaymentMethod:
+			return nil, fmt.Errorf("%w: %s is the default payment method", ErrInvalidPreference, backup)
+		case seen[backup]:
+			return nil, fmt.Errorf("%w: %s is listed more than once", ErrInvalidPreference, backup)
+		}
+		seen[backup] = true
+
+		if err := s.checkAttached(ctx, customerID, backup); err != nil {
+			return nil, err
+		}
+	}
+
+	preference.BackupPaymentMethods = append([]string{}, backups...)
+	return s.store.UpsertPaymentMethodPreference(ctx, preference)
+}
+
+// Forget removes a detached payment method from the customer's preference.
+// When it was the default, the first backup is promoted in its place.
+func (s *Service) Forget(ctx context.Context, tenantID, customerID, paymentMethodID string) error {
+	ctx, span := s.tracer.Start(ctx, "Forget")
+	defer span.End()
+
+	preference, err := s.store.GetPaymentMethodPreference(ctx, tenantID, customerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	backups := make([]string, 0, len(preference.BackupPaymentMethods))
+	for _, backup := range preference.BackupPaymentMethods {
+		if backup != paymentMethodID {
+			backups = append(backups, backup)
+		}
+	}
+
+	if preference.DefaultPaymentMethod == paymentMethodID {
+		preference.DefaultPaymentMethod = ""
+		if len(backups) > 0 {
+			if err := s.provider.SetDefaultPaymentMethod(ctx, customerID, backups[0]); err != nil {
+				return err
+			}
+			preference.DefaultPaymentMethod = backups[0]
+			backups = backups[1:]
+		}
+	} else if len(backups) == len(preference.BackupPaymentMethods) {
+		return nil
+	}
+
+	preference.BackupPaymentMethods = backups
+	_, err = s.store.UpsertPaymentMethodPreference(ctx, preference)
+	return err
+}
+
+// ApplyPreference fills in a charge for a customer that names no payment
+// method with the customer's default, and its backups as fallbacks
+func (s *Service) ApplyPreference(ctx context.Context, tenantID string, request *stripe.ChargeRequest) error {
+	ctx, span := s.tracer.Start(ctx, "ApplyPreference")
+	defer span.End()
+
+	if request.CustomerID == "" || request.PaymentMethod != "" || request.Source != "" {
+		return nil
+	}
+
+	preference, err := s.Get(ctx, tenantID, request.CustomerID)
+	if err != nil {
+		return err
+	}
+	if preference.DefaultPaymentMethod == "" {
+		return nil
+	}
+
+	request.PaymentMethod = preference.DefaultPaymentMethod
+	request.FallbackPaymentMethods = preference.BackupPaymentMethods
+	return nil
+}
+
+// Charge creates a charge, trying each of the request's fallback payment
+// methods in turn when the one before it is declined. The last decline is
+// returned when all of them are.
+func (s *Service) Charge(ctx context.Context, request *stripe.ChargeRequest) (*stripe.Charge, error) {
+	ctx, span := s.tracer.Start(ctx, "Charge")
+	defer span.End()
+
+	charge, err := s.charges.CreateCharge(ctx, request)
+	if err == nil || !CanFallBack(err) {
+		return charge, err
+	}
+
+	for _, fallback := range request.FallbackPaymentMethods {
+		log.Printf("Charge for customer %s declined (%v), falling back to payment method %s", request.CustomerID, err, fallback)
+		span.AddEvent("fallback", trace.WithAttributes(attribute.String("payment_method", fallback)))
+
+		retry := *request
+		retry.PaymentMethod = fallback
+		retry.FallbackPaymentMethods = nil
+		charge, err = s.charges.CreateCharge(ctx, &retry)
+		if err == nil || !CanFallBack(err) {
+			return charge, err
+		}
+	}
+
+	return nil, err
+}
+
+// RecoverSubscription pays the first invoice of a subscription left
+// incomplete by a declined default payment method with each of the
+// customer's backups in turn. The subscription is returned as it is after
+// the attempts, and unchanged when there was nothing to recover.
+func (s *Service) RecoverSubscription(ctx context.Context, tenantID string, subscription *stripe.Subscription) (*stripe.Subscription, error) {
+	ctx, span := s.tracer.Start(ctx, "RecoverSubscription")
+	defer span.End()
+
+	if subscription.Status != "incomplete" || subscription.LatestInvoiceID == "" {
+		return subscription, nil
+	}
+
+	preference, err := s.Get(ctx, tenantID, subscription.CustomerID)
+	if err != nil {
+		return subscription, err
+	}
+
+	for _, backup := range preference.BackupPaymentMethods {
+		log.Printf("Subscription %s incomplete, paying invoice %s with backup payment method %s", subscription.ID, subscription.LatestInvoiceID, backup)
+		span.AddEvent("fallback", trace.WithAttributes(attribute.String("payment_method", backup)))
+
+		_, err := s.invoices.PayInvoice(ctx, subscription.LatestInvoiceID, backup)
+		if err == nil {
+			recovered, err := s.subscriptions.GetSubscription(ctx, subscription.ID)
+			if err != nil {
+				return subscription, err
+			}
+			return recovered, nil
+		}
+		if !CanFallBack(err) {
+			return subscription, err
+		}
+	}
+
+	return subscription, nil
+}
+
+// checkAttached ensures a payment method belongs to the customer
+func (s *Service) checkAttached(ctx context.Context, customerID, paymentMethodID string) error {
+	paymentMethod, err := s.provider.GetPaymentMethod(ctx, paymentMethodID)
+	if err != nil {
+		return err
+	}
+	if paymentMethod.Customer != customerID {
+		return fmt.Errorf("%w: %s", ErrNotAttached, paymentMethodID)
+	}
+	return nil
+}
@@ -0,0 +1,983 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"apis/payments/services/egress"
+	"apis/payments/services/money"
+	"apis/payments/services/receipts"
+
+	"github.com/google/uuid"
+)
+
+// PayPal REST API hosts
+const (
+	paypalProductionURL = "https://api-m.paypal.com"
+	paypalSandboxURL    = "https://api-m.sandbox.paypal.com"
+)
+
+// paypalTokenLeeway renews the access token this long before it expires
+const paypalTokenLeeway = time.Minute
+
+// PayPalGateway implements the PaymentGateway interface for PayPal. PayPal
+// has no customer objects: customers exist only as the owners of vaulted
+// payment tokens, so payment methods are keyed by the PayPal customer ID.
+// Charges are orders, which the payer approves unless they are paid with a
+// vaulted payment method.
+type PayPalGateway struct {
+	clientID     string
+	clientSecret string
+	webhookID    string
+	baseURL      string
+	client       *http.Client
+	handlers     map[string][]PayPalEventHandler
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// NewPayPalGateway creates a new PayPal payment gateway instance.
+// webhook_id identifies the webhook whose deliveries are verified.
+func NewPayPalGateway(config map[string]interface{}) (*PayPalGateway, error) {
+	if err := validatePayPalConfig(config); err != nil {
+		return nil, err
+	}
+
+	baseURL := paypalSandboxURL
+	if config["environment"] == "production" {
+		baseURL = paypalProductionURL
+	}
+	if override, ok := config["base_url"].(string); ok && override != "" {
+		baseURL = strings.TrimSuffix(override, "/")
+	}
+
+	egressConfig, err := egress.LoadConfig("paypal")
+	if err != nil {
+		return nil, &InvalidConfigError{Message: err.Error()}
+	}
+	transport, err := egress.NewTransport(egressConfig)
+	if err != nil {
+		return nil, &InvalidConfigError{Message: err.Error()}
+	}
+
+	webhookID, _ := config["webhook_id"].(string)
+
+	return &PayPalGateway{
+		clientID:     config["client_id"].(string),
+		clientSecret: config["client_secret"].(string),
+		webhookID:    webhookID,
+		baseURL:      baseURL,
+		client:       &http.Client{Transport: transport, Timeout: egressConfig.RequestTimeout},
+		handlers:     make(map[string][]PayPalEventHandler),
+	}, nil
+}
+
+// GetProvider returns the provider name
+func (g *PayPalGateway) GetProvider() string {
+	return "paypal"
+}
+
+// GetCapabilities returns the capabilities supported by PayPal
+func (g *PayPalGateway) GetCapabilities() GatewayCapabilities {
+	return GatewayCapabilities{
+		SupportsCustomers:     false,
+		SupportsCharges:       true,
+		SupportsRefunds:       true,
+		SupportsSubscriptions: true,
+		SupportsDisputes:      false,
+		SupportsConnect:       false,
+		SupportsTax:           false,
+		MaxChargeAmount:       1000000000, // $10,000,000.00 in cents
+		MinChargeAmount:       1,
+		SupportedCurrencies: []string{
+			"aud", "brl", "cad", "cny", "czk", "dkk", "eur", "hkd", "huf", "ils", "jpy", "myr", "mxn",
+			"twd", "nzd", "nok", "php", "pln", "gbp", "sgd", "sek", "chf", "thb", "usd",
+		},
+		SupportsMultiCapture: true,
+		AuthorizationWindow:  29 * 24 * time.Hour, // funds are only honored for the first 3 days
+	}
+}
+
+// PayPal API resources
+
+type paypalAmount struct {
+	CurrencyCode string `json:"currency_code"`
+	Value        string `json:"value"`
+}
+
+type paypalLink struct {
+	Href string `json:"href"`
+	Rel  string `json:"rel"`
+}
+
+type paypalPayment struct {
+	ID             string        `json:"id"`
+	Status         string        `json:"status"`
+	Amount         *paypalAmount `json:"amount"`
+	CustomID       string        `json:"custom_id"`
+	ExpirationTime *time.Time    `json:"expiration_time"` // authorizations only
+	CreateTime     time.Time     `json:"create_time"`
+	UpdateTime     time.Time     `json:"update_time"`
+}
+
+type paypalOrder struct {
+	ID            string `json:"id"`
+	Intent        string `json:"intent"`
+	Status        string `json:"status"`
+	PurchaseUnits []struct {
+		Amount      paypalAmount `json:"amount"`
+		Description string       `json:"description"`
+		CustomID    string       `json:"custom_id"`
+		Payments    struct {
+			Authorizations []paypalPayment `json:"authorizations"`
+			Captures       []paypalPayment `json:"captures"`
+			Refunds        []paypalPayment `json:"refunds"`
+		} `json:"payments"`
+	} `json:"purchase_units"`
+	PaymentSource map[string]struct {
+		VaultID    string `json:"vault_id"`
+		Attributes struct {
+			Vault struct {
+				ID       string `json:"id"`
+				Customer struct {
+					ID string `json:"id"`
+				} `json:"customer"`
+			} `json:"vault"`
+		} `json:"attributes"`
+	} `json:"payment_source"`
+	Links      []paypalLink `json:"links"`
+	CreateTime time.Time    `json:"create_time"`
+	UpdateTime time.Time    `json:"update_time"`
+}
+
+type paypalPaymentToken struct {
+	ID       string `json:"id"`
+	Customer struct {
+		ID string `json:"id"`
+	} `json:"customer"`
+	PaymentSource struct {
+		Card *struct {
+			Brand      string `json:"brand"`
+			LastDigits string `json:"last_digits"`
+			Expiry     string `json:"expiry"` // YYYY-MM
+		} `json:"card"`
+		PayPal *struct {
+			EmailAddress string `json:"email_address"`
+		} `json:"paypal"`
+	} `json:"payment_source"`
+}
+
+type paypalSubscription struct {
+	ID            string `json:"id"`
+	PlanID        string `json:"plan_id"`
+	Status        string `json:"status"`
+	CustomID      string `json:"custom_id"`
+	StatusUpdated string `json:"status_update_time"`
+	BillingInfo   *struct {
+		LastPayment *struct {
+			Time time.Time `json:"time"`
+		} `json:"last_payment"`
+		NextBillingTime time.Time `json:"next_billing_time"`
+	} `json:"billing_info"`
+	Links      []paypalLink `json:"links"`
+	CreateTime time.Time    `json:"create_time"`
+	UpdateTime time.Time    `json:"update_time"`
+}
+
+// Customer management is not supported: PayPal has no customer objects
+
+func (g *PayPalGateway) CreateCustomer(ctx context.Context, req CreateCustomerRequest) (*Customer, error) {
+	return nil, g.notSupported("paypal has no customer objects; customers are created when a payment method is vaulted")
+}
+
+func (g *PayPalGateway) GetCustomer(ctx context.Context, customerID string) (*Customer, error) {
+	return nil, g.notSupported("paypal has no customer objects")
+}
+
+func (g *PayPalGateway) UpdateCustomer(ctx context.Context, customerID string, req UpdateCustomerRequest) (*Customer, error) {
+	return nil, g.notSupported("paypal has no customer objects")
+}
+
+func (g *PayPalGateway) DeleteCustomer(ctx context.Context, customerID string) error {
+	return g.notSupported("paypal has no customer objects; remove the customer's payment methods instead")
+}
+
+func (g *PayPalGateway) ListCustomers(ctx context.Context, req ListCustomersRequest) (*CustomerList, error) {
+	return nil, g.notSupported("paypal has no customer objects")
+}
+
+// AddPaymentMethod vaults a payment method from a setup token the payer has
+// approved, passed in metadata setup_token_id. The customer ID is kept by
+// PayPal; pass an empty one to have PayPal assign it.
+func (g *PayPalGateway) AddPaymentMethod(ctx context.Context, customerID string, req AddPaymentMethodRequest) (*PaymentMethod, error) {
+	setupTokenID, _ := req.Metadata["setup_token_id"].(string)
+	if setupTokenID == "" {
+		return nil, &PaymentError{Code: "invalid_request", Message: "metadata setup_token_id is required to vault a payment method with paypal", Provider: "paypal"}
+	}
+
+	body := map[string]interface{}{
+		"payment_source": map[string]interface{}{
+			"token": map[string]interface{}{"id": setupTokenID, "type": "SETUP_TOKEN"},
+		},
+	}
+	if customerID != "" {
+		body["customer"] = map[string]interface{}{"id": customerID}
+	}
+
+	var token paypalPaymentToken
+	if err := g.do(ctx, http.MethodPost, "/v3/vault/payment-tokens", nil, body, &token); err != nil {
+		return nil, g.paymentError("payment_method_creation_failed", "failed to vault payment method", err)
+	}
+
+	return convertPayPalPaymentToken(&token), nil
+}
+
+func (g *PayPalGateway) RemovePaymentMethod(ctx context.Context, customerID string, paymentMethodID string) error {
+	if err := g.do(ctx, http.MethodDelete, "/v3/vault/payment-tokens/"+url.PathEscape(paymentMethodID), nil, nil, nil); err != nil {
+		return g.paymentError("payment_method_removal_failed", "failed to remove payment method", err)
+	}
+
+	return nil
+}
+
+// ListPaymentMethods lists a page of the customer's vaulted payment methods.
+// PayPal pages by number, so cursors are the number of the next page.
+func (g *PayPalGateway) ListPaymentMethods(ctx context.Context, customerID string, req ListPaymentMethodsRequest) (*PaymentMethodList, error) {
+	page := 1
+	if req.Cursor != "" {
+		number, err := strconv.Atoi(req.Cursor)
+		if err != nil || number < 1 {
+			return nil, &PaymentError{Code: "invalid_request", Message: "invalid cursor: " + req.Cursor, Provider: "paypal"}
+		}
+		page = number
+	}
+
+	query := url.Values{"customer_id": {customerID}, "page": {strconv.Itoa(page)}}
+	if req.Limit > 0 {
+		query.Set("page_size", strconv.Itoa(req.Limit))
+	}
+
+	var resp struct {
+		PaymentTokens []paypalPaymentToken `json:"payment_tokens"`
+		TotalPages    int                  `json:"total_pages"`
+	}
+	if err := g.do(ctx, http.MethodGet, "/v3/vault/payment-tokens", query, nil, &resp); err != nil {
+		return nil, g.paymentError("payment_method_list_failed", "failed to list payment methods", err)
+	}
+
+	paymentMethods := make([]*PaymentMethod, len(resp.PaymentTokens))
+	for i := range resp.PaymentTokens {
+		paymentMethods[i] = convertPayPalPaymentToken(&resp.PaymentTokens[i])
+	}
+
+	list := &PaymentMethodList{PaymentMethods: paymentMethods, HasMore: page < resp.TotalPages}
+	if list.HasMore {
+		list.NextCursor = strconv.Itoa(page + 1)
+	}
+
+	return list, nil
+}
+
+// Payment processing implementation
+
+// CreateCharge creates an order. Orders paid with a vaulted payment method
+// complete, or are authorized when not captured, straight away. Otherwise
+// the payer approves the order at the approve_url returned in metadata and
+// it is captured afterwards with CaptureCharge.
+func (g *PayPalGateway) CreateCharge(ctx context.Context, req CreateChargeRequest) (*Charge, error) {
+	if err := receipts.ValidateDescriptor("paypal", req.StatementDescriptor, req.StatementDescriptorSuffix); err != nil {
+		return nil, &PaymentError{Code: "invalid_request", Message: err.Error(), Provider: "paypal"}
+	}
+
+	intent := "CAPTURE"
+	if !req.Capture {
+		intent = "AUTHORIZE"
+	}
+
+	purchaseUnit := map[string]interface{}{
+		"amount": paypalMoney(req.Amount, req.Currency),
+	}
+	if req.Description != "" {
+		purchaseUnit["description"] = req.Description
+	}
+	if customID := paypalCustomID(req.Metadata); customID != "" {
+		purchaseUnit["custom_id"] = customID
+	}
+	// The soft descriptor follows the merchant's name on the statement
+	if req.StatementDescriptorSuffix != "" {
+		purchaseUnit["soft_descriptor"] = req.StatementDescriptorSuffix
+	}
+	body := map[string]interface{}{
+		"intent":         intent,
+		"purchase_units": []interface{}{purchaseUnit},
+	}
+	if req.PaymentMethodID != "" {
+		body["payment_source"] = map[string]interface{}{
+			"token": map[string]interface{}{"id": req.PaymentMethodID, "type": "PAYMENT_METHOD_TOKEN"},
+		}
+	}
+
+	var order paypalOrder
+	if err := g.do(ctx, http.MethodPost, "/v2/checkout/orders", nil, body, &order); err != nil {
+		return nil, g.paymentError("charge_creation_failed", "failed to create charge", err)
+	}
+
+	// Vaulted payment methods need no approval, so the order is settled now
+	if req.PaymentMethodID != "" && order.Status == "APPROVED" {
+		action := "/capture"
+		if intent == "AUTHORIZE" {
+			action = "/authorize"
+		}
+		if err := g.do(ctx, http.MethodPost, "/v2/checkout/orders/"+url.PathEscape(order.ID)+action, nil, map[string]interface{}{}, &order); err != nil {
+			return nil, g.paymentError("charge_creation_failed", "failed to settle charge", err)
+		}
+	}
+
+	charge := convertPayPalOrder(&order)
+	if charge.CustomerID == "" {
+		charge.CustomerID = req.CustomerID
+	}
+	return charge, nil
+}
+
+func (g *PayPalGateway) GetCharge(ctx context.Context, chargeID string) (*Charge, error) {
+	order, err := g.getOrder(ctx, chargeID)
+	if err != nil {
+		return nil, g.paymentError("charge_retrieval_failed", "failed to retrieve charge", err)
+	}
+
+	return convertPayPalOrder(order), nil
+}
+
+// UpdateCharge updates the description and custom_id metadata of an order
+// that has not been completed yet
+func (g *PayPalGateway) UpdateCharge(ctx context.Context, chargeID string, req UpdateChargeRequest) (*Charge, error) {
+	var patches []map[string]interface{}
+	if req.Description != "" {
+		patches = append(patches, map[string]interface{}{
+			"op": "replace", "path": "/purchase_units/@reference_id=='default'/description", "value": req.Description,
+		})
+	}
+	if customID := paypalCustomID(req.Metadata); customID != "" {
+		patches = append(patches, map[string]interface{}{
+			"op": "replace", "path": "/purchase_units/@reference_id=='default'/custom_id", "value": customID,
+		})
+	}
+
+	if len(patches) > 0 {
+		if err := g.do(ctx, http.MethodPatch, "/v2/checkout/orders/"+url.PathEscape(chargeID), nil, patches, nil); err != nil {
+			return nil, g.paymentError("charge_update_failed", "failed to update charge", err)
+		}
+	}
+
+	return g.GetCharge(ctx, chargeID)
+}
+
+// CaptureCharge captures an approved order. An authorized order's
+// authorization is captured instead, for a smaller amount if one is given,
+// and stays open for further captures if asked to.
+func (g *PayPalGateway) CaptureCharge(ctx context.Context, chargeID string, req CaptureChargeRequest) (*Charge, error) {
+	order, err := g.getOrder(ctx, chargeID)
+	if err != nil {
+		return nil, g.paymentError("charge_capture_failed", "failed to retrieve charge", err)
+	}
+	orderPath := "/v2/checkout/orders/" + url.PathEscape(chargeID)
+
+	if order.Intent != "AUTHORIZE" {
+		if req.Amount > 0 || req.KeepOpen {
+			return nil, g.notSupported("paypal captures orders in full; create the charge without capture to capture less")
+		}
+		if err := g.do(ctx, http.MethodPost, orderPath+"/capture", nil, map[string]interface{}{}, order); err != nil {
+			return nil, g.paymentError("charge_capture_failed", "failed to capture charge", err)
+		}
+		return convertPayPalOrder(order), nil
+	}
+
+	// Authorize an order the payer approved before capturing it
+	if order.Status == "APPROVED" {
+		if err := g.do(ctx, http.MethodPost, orderPath+"/authorize", nil, map[string]interface{}{}, order); err != nil {
+			return nil, g.paymentError("charge_capture_failed", "failed to authorize charge", err)
+		}
+	}
+	if len(order.PurchaseUnits) == 0 || len(order.PurchaseUnits[0].Payments.Authorizations) == 0 {
+		return nil, &PaymentError{Code: "charge_capture_failed", Message: "charge has no authorization to capture", Provider: "paypal"}
+	}
+	unit := order.PurchaseUnits[0]
+
+	body := map[string]interface{}{"final_capture": !req.KeepOpen}
+	if req.Amount > 0 {
+		body["amount"] = paypalMoney(req.Amount, unit.Amount.CurrencyCode)
+	}
+	authorizationID := unit.Payments.Authorizations[0].ID
+	if err := g.do(ctx, http.MethodPost, "/v2/payments/authorizations/"+url.PathEscape(authorizationID)+"/capture", nil, body, nil); err != nil {
+		return nil, g.paymentError("charge_capture_failed", "failed to capture charge", err)
+	}
+
+	return g.GetCharge(ctx, chargeID)
+}
+
+// VoidCharge voids an authorized order's authorization. Orders the payer
+// has not approved hold no funds and are left to expire.
+func (g *PayPalGateway) VoidCharge(ctx context.Context, chargeID string) (*Charge, error) {
+	order, err := g.getOrder(ctx, chargeID)
+	if err != nil {
+		return nil, g.paymentError("charge_void_failed", "failed to retrieve charge", err)
+	}
+	if order.Intent != "AUTHORIZE" || len(order.PurchaseUnits) == 0 || len(order.PurchaseUnits[0].Payments.Authorizations) == 0 {
+		return nil, &PaymentError{Code: "charge_void_failed", Message: "charge has no authorization to void", Provider: "paypal"}
+	}
+
+	authorizationID := order.PurchaseUnits[0].Payments.Authorizations[0].ID
+	if err := g.do(ctx, http.MethodPost, "/v2/payments/authorizations/"+url.PathEscape(authorizationID)+"/void", nil, map[string]interface{}{}, nil); err != nil {
+		return nil, g.paymentError("charge_void_failed", "failed to void charge", err)
+	}
+
+	return g.GetCharge(ctx, chargeID)
+}
+
+// ListCharges is not supported: PayPal has no API listing orders
+func (g *PayPalGateway) ListCharges(ctx context.Context, req ListChargesRequest) (*ChargeList, error) {
+	return nil, g.notSupported("paypal orders cannot be listed")
+}
+
+// Refund processing implementation
+
+// CreateRefund refunds an order's capture, fully when no amount is given.
+// The order ID is kept as the refund's custom_id so refunds can be traced
+// back to their charge.
+func (g *PayPalGateway) CreateRefund(ctx context.Context, req CreateRefundRequest) (*Refund, error) {
+	order, err := g.getOrder(ctx, req.ChargeID)
+	if err != nil {
+		return nil, g.paymentError("refund_creation_failed", "failed to retrieve charge", err)
+	}
+	if len(order.PurchaseUnits) == 0 || len(order.PurchaseUnits[0].Payments.Captures) == 0 {
+		return nil, &PaymentError{Code: "refund_creation_failed", Message: "charge has not been captured", Provider: "paypal"}
+	}
+	unit := order.PurchaseUnits[0]
+
+	body := map[string]interface{}{"custom_id": req.ChargeID}
+	if req.Amount > 0 {
+		body["amount"] = paypalMoney(req.Amount, unit.Amount.CurrencyCode)
+	}
+	if req.Reason != "" {
+		body["note_to_payer"] = req.Reason
+	}
+
+	var refund paypalPayment
+	captureID := unit.Payments.Captures[0].ID
+	if err := g.do(ctx, http.MethodPost, "/v2/payments/captures/"+url.PathEscape(captureID)+"/refund", nil, body, &refund); err != nil {
+		return nil, g.paymentError("refund_creation_failed", "failed to create refund", err)
+	}
+
+	// The refund response carries only its ID and status
+	result := convertPayPalRefund(&refund)
+	result.ChargeID = req.ChargeID
+	result.Reason = req.Reason
+	if refund.Amount == nil {
+		result.Amount = req.Amount
+		result.Currency = strings.ToLower(unit.Amount.CurrencyCode)
+	}
+	return result, nil
+}
+
+func (g *PayPalGateway) GetRefund(ctx context.Context, refundID string) (*Refund, error) {
+	var refund paypalPayment
+	if err := g.do(ctx, http.MethodGet, "/v2/payments/refunds/"+url.PathEscape(refundID), nil, nil, &refund); err != nil {
+		return nil, g.paymentError("refund_retrieval_failed", "failed to retrieve refund", err)
+	}
+
+	return convertPayPalRefund(&refund), nil
+}
+
+// UpdateRefund is not supported: PayPal refunds cannot be changed
+func (g *PayPalGateway) UpdateRefund(ctx context.Context, refundID string, req UpdateRefundRequest) (*Refund, error) {
+	return nil, g.notSupported("paypal refunds cannot be updated")
+}
+
+// ListRefunds lists the refunds of a charge from its order. The order holds
+// all of them, so pages are cut here, continuing after the cursor refund.
+func (g *PayPalGateway) ListRefunds(ctx context.Context, req ListRefundsRequest) (*RefundList, error) {
+	if req.ChargeID == "" {
+		return nil, g.notSupported("paypal refunds can only be listed for a charge")
+	}
+
+	order, err := g.getOrder(ctx, req.ChargeID)
+	if err != nil {
+		return nil, g.paymentError("refund_list_failed", "failed to retrieve charge", err)
+	}
+
+	var refunds []*Refund
+	for _, unit := range order.PurchaseUnits {
+		for i := range unit.Payments.Refunds {
+			refund := convertPayPalRefund(&unit.Payments.Refunds[i])
+			refund.ChargeID = req.ChargeID
+			refunds = append(refunds, refund)
+		}
+	}
+
+	if req.Cursor != "" {
+		after := slices.IndexFunc(refunds, func(refund *Refund) bool { return refund.ID == req.Cursor })
+		if after < 0 {
+			return nil, &PaymentError{Code: "invalid_request", Message: "invalid cursor: " + req.Cursor, Provider: "paypal"}
+		}
+		refunds = refunds[after+1:]
+	}
+
+	list := &RefundList{Refunds: refunds}
+	if req.Limit > 0 && len(refunds) > req.Limit {
+		list.Refunds = refunds[:req.Limit]
+		list.HasMore = true
+		list.NextCursor = list.Refunds[req.Limit-1].ID
+	}
+	list.Total = len(list.Refunds)
+
+	return list, nil
+}
+
+// Subscription management implementation
+
+// CreateSubscription subscribes to a billing plan. The subscriber approves
+// it at the approve_url returned in metadata, after which it is active.
+// The customer ID is kept as the subscription's custom_id.
+func (g *PayPalGateway) CreateSubscription(ctx context.Context, req CreateSubscriptionRequest) (*Subscription, error) {
+	body := map[string]interface{}{
+		"plan_id":   req.PlanID,
+		"custom_id": req.CustomerID,
+	}
+
+	var subscription paypalSubscription
+	if err := g.do(ctx, http.MethodPost, "/v1/billing/subscriptions", nil, body, &subscription); err != nil {
+		return nil, g.paymentError("subscription_creation_failed", "failed to create subscription", err)
+	}
+
+	result := convertPayPalSubscription(&subscription)
+	for key, value := range req.Metadata {
+		result.Metadata[key] = value
+	}
+	return result, nil
+}
+
+func (g *PayPalGateway) GetSubscription(ctx context.Context, subscriptionID string) (*Subscription, error) {
+	var subscription paypalSubscription
+	if err := g.do(ctx, http.MethodGet, "/v1/billing/subscriptions/"+url.PathEscape(subscriptionID), nil, nil, &subscription); err != nil {
+		return nil, g.paymentError("subscription_retrieval_failed", "failed to retrieve subscription", err)
+	}
+
+	return convertPayPalSubscription(&subscription), nil
+}
+
+// UpdateSubscription revises the subscription onto another plan. The
+// subscriber may have to approve the revision at the returned approve_url.
+func (g *PayPalGateway) UpdateSubscription(ctx context.Context, subscriptionID string, req UpdateSubscriptionRequest) (*Subscription, error) {
+	if req.PlanID == "" {
+		return g.GetSubscription(ctx, subscriptionID)
+	}
+
+	var revision struct {
+		Links []paypalLink `json:"links"`
+	}
+	body := map[string]interface{}{"plan_id": req.PlanID}
+	if err := g.do(ctx, http.MethodPost, "/v1/billing/subscriptions/"+url.PathEscape(subscriptionID)+"/revise", nil, body, &revision); err != nil {
+		return nil, g.paymentError("subscription_update_failed", "failed to revise subscription", err)
+	}
+
+	subscription, err := g.GetSubscription(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if approveURL := paypalLinkHref(revision.Links, "approve"); approveURL != "" {
+		subscription.Metadata["approve_url"] = approveURL
+	}
+	return subscription, nil
+}
+
+// CancelSubscription cancels a subscription immediately. PayPal cannot
+// cancel at the end of the period.
+func (g *PayPalGateway) CancelSubscription(ctx context.Context, subscriptionID string, req CancelSubscriptionRequest) (*Subscription, error) {
+	if req.AtPeriodEnd {
+		return nil, g.notSupported("paypal subscriptions can only be cancelled immediately")
+	}
+
+	body := map[string]interface{}{"reason": "Cancelled by merchant"}
+	if err := g.do(ctx, http.MethodPost, "/v1/billing/subscriptions/"+url.PathEscape(subscriptionID)+"/cancel", nil, body, nil); err != nil {
+		return nil, g.paymentError("subscription_cancellation_failed", "failed to cancel subscription", err)
+	}
+
+	return g.GetSubscription(ctx, subscriptionID)
+}
+
+// ListSubscriptions is not supported: PayPal has no API listing subscriptions
+func (g *PayPalGateway) ListSubscriptions(ctx context.Context, req ListSubscriptionsRequest) (*SubscriptionList, error) {
+	return nil, g.notSupported("paypal subscriptions cannot be listed")
+}
+
+// PayPal API helpers
+
+// paypalAPIError is the error PayPal returns with non-2xx responses
+type paypalAPIError struct {
+	Status  int
+	Name    string `json:"name"`
+	Message string `json:"message"`
+	Details []struct {
+		Issue       string `json:"issue"`
+		Description string `json:"description"`
+	} `json:"details"`
+}
+
+// HTTPStatus returns the status of the response PayPal returned the error with
+func (e *paypalAPIError) HTTPStatus() int {
+	return e.Status
+}
+
+func (e *paypalAPIError) Error() string {
+	message := fmt.Sprintf("paypal %s (%d): %s", e.Name, e.Status, e.Message)
+	if len(e.Details) > 0 {
+		message += fmt.Sprintf(" [%s: %s]", e.Details[0].Issue, e.Details[0].Description)
+	}
+	return message
+}
+
+// do sends an authenticated request to the PayPal API and decodes the
+// response into out when it is not nil. POSTs carry a fresh request ID so
+// PayPal treats retries of the same call as one.
+func (g *PayPalGateway) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	token, err := g.token(ctx)
+	if err != nil {
+		return err
+	}
+
+	endpoint := g.baseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if method == http.MethodPost {
+		req.Header.Set("PayPal-Request-Id", uuid.New().String())
+		req.Header.Set("Prefer", "return=representation")
+	}
+
+	return g.send(req, out)
+}
+
+// token returns a cached OAuth access token, fetching a new one when it is
+// about to expire
+func (g *PayPalGateway) token(ctx context.Context) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.accessToken != "" && time.Now().Before(g.expiresAt) {
+		return g.accessToken, nil
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.baseURL+"/v1/oauth2/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to build token request: %w", err)
+	}
+	req.SetBasicAuth(g.clientID, g.clientSecret)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var resp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := g.send(req, &resp); err != nil {
+		return "", fmt.Errorf("failed to get access token: %w", err)
+	}
+
+	g.accessToken = resp.AccessToken
+	g.expiresAt = time.Now().Add(time.Duration(resp.ExpiresIn)*time.Second - paypalTokenLeeway)
+	return g.accessToken, nil
+}
+
+// send sends a request and decodes the response or PayPal's error
+func (g *PayPalGateway) send(req *http.Request, out interface{}) error {
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	payload, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		apiErr := &paypalAPIError{}
+		if json.Unmarshal(payload, apiErr) != nil || apiErr.Name == "" {
+			apiErr = &paypalAPIError{Name: "HTTP_ERROR", Message: http.StatusText(resp.StatusCode)}
+		}
+		apiErr.Status = resp.StatusCode
+		return apiErr
+	}
+
+	if out != nil && len(payload) > 0 {
+		if err := json.Unmarshal(payload, out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (g *PayPalGateway) getOrder(ctx context.Context, orderID string) (*paypalOrder, error) {
+	var order paypalOrder
+	if err := g.do(ctx, http.MethodGet, "/v2/checkout/orders/"+url.PathEscape(orderID), nil, nil, &order); err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (g *PayPalGateway) notSupported(message string) error {
+	return &PaymentError{Code: "not_supported", Message: message, Provider: "paypal"}
+}
+
+func (g *PayPalGateway) paymentError(code, message string, err error) error {
+	return &PaymentError{
+		Code:     code,
+		Message:  fmt.Sprintf("%s: %v", message, err),
+		Provider: "paypal",
+	}
+}
+
+// paypalMoney converts minor units to PayPal's decimal amount
+func paypalMoney(amount int64, currency string) paypalAmount {
+	return paypalAmount{
+		CurrencyCode: strings.ToUpper(currency),
+		Value:        money.FormatDecimal(amount, strings.ToLower(currency)),
+	}
+}
+
+// parsePayPalAmount converts PayPal's decimal amount to minor units
+func parsePayPalAmount(amount *paypalAmount) (int64, string) {
+	if amount == nil {
+		return 0, ""
+	}
+	currency := strings.ToLower(amount.CurrencyCode)
+	minor, _ := money.ParseDecimal(amount.Value, currency)
+	return minor, currency
+}
+
+// paypalCustomID returns the custom_id metadata value, the only metadata
+// PayPal stores on orders
+func paypalCustomID(metadata map[string]interface{}) string {
+	customID, _ := metadata["custom_id"].(string)
+	return customID
+}
+
+func paypalLinkHref(links []paypalLink, rel string) string {
+	for _, link := range links {
+		if link.Rel == rel || (rel == "approve" && link.Rel == "payer-action") {
+			return link.Href
+		}
+	}
+	return ""
+}
+
+// Conversion helper methods
+
+func convertPayPalPaymentToken(pt *paypalPaymentToken) *PaymentMethod {
+	pm := &PaymentMethod{
+		ID:         pt.ID,
+		CustomerID: pt.Customer.ID,
+		Type:       "paypal",
+		ProviderID: pt.ID,
+		Provider:   "paypal",
+	}
+
+	if card := pt.PaymentSource.Card; card != nil {
+		pm.Type = "card"
+		pm.Card = &Card{
+			Brand: strings.ToLower(card.Brand),
+			Last4: card.LastDigits,
+		}
+		if expiry, err := time.Parse("2006-01", card.Expiry); err == nil {
+			pm.Card.ExpMonth = int(expiry.Month())
+			pm.Card.ExpYear = expiry.Year()
+		}
+	}
+	if account := pt.PaymentSource.PayPal; account != nil {
+		pm.Metadata = map[string]interface{}{"email": account.EmailAddress}
+	}
+
+	return pm
+}
+
+// convertPayPalOrder converts an order to a charge. Orders waiting for the
+// payer are pending, with the approve_url in metadata; authorized orders
+// await capture.
+func convertPayPalOrder(po *paypalOrder) *Charge {
+	c := &Charge{
+		ID:         po.ID,
+		Status:     "pending",
+		Metadata:   map[string]interface{}{},
+		CreatedAt:  po.CreateTime,
+		UpdatedAt:  po.UpdateTime,
+		ProviderID: po.ID,
+		Provider:   "paypal",
+	}
+
+	if len(po.PurchaseUnits) > 0 {
+		unit := po.PurchaseUnits[0]
+		c.Amount, c.Currency = parsePayPalAmount(&unit.Amount)
+		c.Description = unit.Description
+		if unit.CustomID != "" {
+			c.Metadata["custom_id"] = unit.CustomID
+		}
+
+		switch {
+		case len(unit.Payments.Captures) > 0:
+			c.Status = convertPayPalPaymentStatus(unit.Payments.Captures[0].Status)
+		case len(unit.Payments.Authorizations) > 0:
+			c.Status = convertPayPalPaymentStatus(unit.Payments.Authorizations[0].Status)
+		}
+		// Authorizations still holding funds lapse at their expiration time
+		if len(unit.Payments.Authorizations) > 0 {
+			switch authorization := unit.Payments.Authorizations[0]; authorization.Status {
+			case "CREATED", "PENDING", "PARTIALLY_CAPTURED":
+				c.CaptureBefore = authorization.ExpirationTime
+			}
+		}
+	}
+
+	switch po.Status {
+	case "VOIDED":
+		c.Status = "canceled"
+	case "CREATED", "PAYER_ACTION_REQUIRED":
+		if approveURL := paypalLinkHref(po.Links, "approve"); approveURL != "" {
+			c.Metadata["approve_url"] = approveURL
+		}
+	case "APPROVED":
+		if po.Intent == "AUTHORIZE" {
+			c.Status = "requires_capture"
+		}
+	}
+
+	for _, source := range po.PaymentSource {
+		if source.VaultID != "" {
+			c.PaymentMethodID = source.VaultID
+		}
+		if vault := source.Attributes.Vault; vault.ID != "" {
+			c.PaymentMethodID = vault.ID
+			c.CustomerID = vault.Customer.ID
+		}
+	}
+
+	return c
+}
+
+// convertPayPalPaymentStatus maps capture and authorization statuses
+func convertPayPalPaymentStatus(status string) string {
+	switch status {
+	case "COMPLETED", "CAPTURED", "PARTIALLY_CAPTURED", "REFUNDED", "PARTIALLY_REFUNDED":
+		return "succeeded"
+	case "CREATED":
+		// Created authorizations await capture
+		return "requires_capture"
+	case "DECLINED", "FAILED":
+		return "failed"
+	case "VOIDED", "EXPIRED":
+		return "canceled"
+	default:
+		return "pending"
+	}
+}
+
+// convertPayPalRefund converts a refund. The order ID is the refund's custom_id.
+func convertPayPalRefund(pr *paypalPayment) *Refund {
+	amount, currency := parsePayPalAmount(pr.Amount)
+
+	status := "pending"
+	switch pr.Status {
+	case "COMPLETED":
+		status = "succeeded"
+	case "FAILED":
+		status = "failed"
+	case "CANCELLED":
+		status = "canceled"
+	}
+
+	return &Refund{
+		ID:         pr.ID,
+		ChargeID:   pr.CustomID,
+		Amount:     amount,
+		Currency:   currency,
+		Status:     status,
+		CreatedAt:  pr.CreateTime,
+		UpdatedAt:  pr.UpdateTime,
+		ProviderID: pr.ID,
+		Provider:   "paypal",
+	}
+}
+
+// convertPayPalSubscription converts a subscription. Subscriptions waiting
+// for approval are incomplete, with the approve_url in metadata.
+func convertPayPalSubscription(ps *paypalSubscription) *Subscription {
+	status := strings.ToLower(ps.Status)
+	switch ps.Status {
+	case "APPROVAL_PENDING", "APPROVED":
+		status = "incomplete"
+	case "SUSPENDED":
+		status = "paused"
+	case "CANCELLED":
+		status = "canceled"
+	case "EXPIRED":
+		status = "incomplete_expired"
+	}
+
+	s := &Subscription{
+		ID:         ps.ID,
+		CustomerID: ps.CustomID,
+		PlanID:     ps.PlanID,
+		Status:     status,
+		Metadata:   map[string]interface{}{},
+		CreatedAt:  ps.CreateTime,
+		UpdatedAt:  ps.UpdateTime,
+		ProviderID: ps.ID,
+		Provider:   "paypal",
+	}
+
+	if approveURL := paypalLinkHref(ps.Links, "approve"); approveURL != "" && status == "incomplete" {
+		s.Metadata["approve_url"] = approveURL
+	}
+	if ps.BillingInfo != nil {
+		s.CurrentPeriodEnd = ps.BillingInfo.NextBillingTime
+		if ps.BillingInfo.LastPayment != nil {
+			s.CurrentPeriodStart = ps.BillingInfo.LastPayment.Time
+		}
+	}
+
+	return s
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// PayPalEvent is a PayPal webhook event
+type PayPalEvent struct {
+	ID           string          `json:"id"`
+	EventType    string          `json:"event_type"`
+	ResourceType string          `json:"resource_type"`
+	CreateTime   time.Time       `json:"create_time"`
+	Resource     json.RawMessage `json:"resource"`
+}
+
+// PayPalEventHandler handles one type of PayPal event
+type PayPalEventHandler func(ctx context.Context, event PayPalEvent) error
+
+// PayPalTransmission holds the PAYPAL-* headers PayPal signs a webhook delivery with
+type PayPalTransmission struct {
+	AuthAlgo         string
+	CertURL          string
+	TransmissionID   string
+	TransmissionSig  string
+	TransmissionTime string
+}
+
+// PayPalTransmissionFromHeaders reads the signature headers of a delivery
+func PayPalTransmissionFromHeaders(header http.Header) PayPalTransmission {
+	return PayPalTransmission{
+		AuthAlgo:         header.Get("PAYPAL-AUTH-ALGO"),
+		CertURL:          header.Get("PAYPAL-CERT-URL"),
+		TransmissionID:   header.Get("PAYPAL-TRANSMISSION-ID"),
+		TransmissionSig:  header.Get("PAYPAL-TRANSMISSION-SIG"),
+		TransmissionTime: header.Get("PAYPAL-TRANSMISSION-TIME"),
+	}
+}
+
+// On registers a handler for a PayPal event type, e.g. PAYMENT.CAPTURE.COMPLETED
+func (g *PayPalGateway) On(eventType string, handler PayPalEventHandler) {
+	g.handlers[eventType] = append(g.handlers[eventType], handler)
+}
+
+// ConstructEvent verifies a delivery with PayPal and parses the event payload.
+// PayPal checks the signature against the certificate it was signed with, so
+// verification needs a round trip to the API.
+func (g *PayPalGateway) ConstructEvent(ctx context.Context, payload []byte, transmission PayPalTransmission) (PayPalEvent, error) {
+	if g.webhookID == "" {
+		return PayPalEvent{}, fmt.Errorf("webhook id is not configured")
+	}
+	if transmission.TransmissionID == "" || transmission.TransmissionSig == "" {
+		return PayPalEvent{}, fmt.Errorf("failed to verify webhook signature: missing transmission headers")
+	}
+
+	body := map[string]interface{}{
+		"auth_algo":         transmission.AuthAlgo,
+		"cert_url":          transmission.CertURL,
+		"transmission_id":   transmission.TransmissionID,
+		"transmission_sig":  transmission.TransmissionSig,
+		"transmission_time": transmission.TransmissionTime,
+		"webhook_id":        g.webhookID,
+		"webhook_event":     json.RawMessage(payload),
+	}
+
+	var resp struct {
+		VerificationStatus string `json:"verification_status"`
+	}
+	if err := g.do(ctx, http.MethodPost, "/v1/notifications/verify-webhook-signature", nil, body, &resp); err != nil {
+		return PayPalEvent{}, fmt.Errorf("failed to verify webhook signature: %w", err)
+	}
+	if resp.VerificationStatus != "SUCCESS" {
+		return PayPalEvent{}, fmt.Errorf("failed to verify webhook signature: verification status %s", resp.VerificationStatus)
+	}
+
+	var event PayPalEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return PayPalEvent{}, fmt.Errorf("failed to parse event: %w", err)
+	}
+
+	return event, nil
+}
+
+// HandleWebhook verifies a delivery and runs all handlers registered for its type
+func (g *PayPalGateway) HandleWebhook(ctx context.Context, payload []byte, transmission PayPalTransmission) (PayPalEvent, error) {
+	event, err := g.ConstructEvent(ctx, payload, transmission)
+	if err != nil {
+		return PayPalEvent{}, err
+	}
+
+	for _, handler := range g.handlers[event.EventType] {
+		if err := handler(ctx, event); err != nil {
+			return event, fmt.Errorf("failed to handle %s event %s: %w", event.EventType, event.ID, err)
+		}
+	}
+
+	return event, nil
+}
+
+// Refund returns the refund in a PAYMENT.CAPTURE.REFUNDED event
+func (e PayPalEvent) Refund() (*Refund, error) {
+	var refund paypalPayment
+	if err := json.Unmarshal(e.Resource, &refund); err != nil {
+		return nil, fmt.Errorf("failed to parse refund: %w", err)
+	}
+
+	return convertPayPalRefund(&refund), nil
+}
+
+// Subscription returns the subscription in a BILLING.SUBSCRIPTION.* event
+func (e PayPalEvent) Subscription() (*Subscription, error) {
+	var subscription paypalSubscription
+	if err := json.Unmarshal(e.Resource, &subscription); err != nil {
+		return nil, fmt.Errorf("failed to parse subscription: %w", err)
+	}
+
+	return convertPayPalSubscription(&subscription), nil
+}
+
+// OrderID returns the order a CHECKOUT.ORDER.* or PAYMENT.CAPTURE.* event
+// belongs to. Capture events carry it in their related IDs, so the charge
+// can be fetched with GetCharge.
+func (e PayPalEvent) OrderID() (string, error) {
+	var resource struct {
+		ID                string `json:"id"`
+		SupplementaryData struct {
+			RelatedIDs struct {
+				OrderID string `json:"order_id"`
+			} `json:"related_ids"`
+		} `json:"supplementary_data"`
+	}
+	if err := json.Unmarshal(e.Resource, &resource); err != nil {
+		return "", fmt.Errorf("failed to parse resource: %w", err)
+	}
+
+	if e.ResourceType == "checkout-order" {
+		return resource.ID, nil
+	}
+	if resource.SupplementaryData.RelatedIDs.OrderID == "" {
+		return "", fmt.Errorf("%s event %s has no order", e.EventType, e.ID)
+	}
+	return resource.SupplementaryData.RelatedIDs.OrderID, nil
+}
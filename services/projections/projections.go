@@ -0,0 +1,69 @@
+package projections
+
+import (
+	"context"
+	"time"
+
+	"apis/payments/services/stripe"
+)
+
+// DefaultTenantID is used for charges that carry no tenant metadata
+const DefaultTenantID = "default"
+
+// ChargeRow is a denormalized charge list row combining the charge with its
+// customer, plan and latest refund
+type ChargeRow struct {
+	ChargeID         string    `json:"charge_id"`
+	TenantID         string    `json:"tenant_id"`
+	CustomerID       string    `json:"customer_id,omitempty"`
+	CustomerEmail    string    `json:"customer_email,omitempty"`
+	CustomerName     string    `json:"customer_name,omitempty"`
+	PlanName         string    `json:"plan_name,omitempty"`
+	Amount           int64     `json:"amount"`
+	AmountRefunded   int64     `json:"amount_refunded"`
+	Currency         string    `json:"currency"`
+	Status           string    `json:"status"`
+	Description      string    `json:"description,omitempty"`
+	LastRefundID     string    `json:"last_refund_id,omitempty"`
+	LastRefundStatus string    `json:"last_refund_status,omitempty"`
+	ChargeCreated    time.Time `json:"charge_created"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// ChargeFilter narrows a charge list query
+type ChargeFilter struct {
+	TenantID   string
+	CustomerID string
+	Status     string
+	Limit      int
+	Offset     int
+}
+
+// Store persists charge list rows
+type Store interface {
+	UpsertChargeRow(ctx context.Context, row *ChargeRow) error
+	UpdateChargeRowRefund(ctx context.Context, chargeID, refundID, refundStatus string) error
+	UpdateChargeRowsCustomer(ctx context.Context, customerID, email, name string) error
+	ListChargeRows(ctx context.Context, filter ChargeFilter) ([]*ChargeRow, error)
+}
+
+// ChargeLookup fetches the current state of a charge
+type ChargeLookup interface {
+	GetCharge(ctx context.Context, chargeID string) (*stripe.Charge, error)
+	ListCharges(ctx context.Context, customerID string, page stripe.Page) ([]*stripe.Charge, error)
+}
+
+// CustomerLookup fetches customer details
+type CustomerLookup interface {
+	GetCustomer(ctx context.Context, customerID string) (*stripe.Customer, error)
+}
+
+// RefundLookup lists the refunds of a charge, newest first
+type RefundLookup interface {
+	ListRefunds(ctx context.Context, chargeID string, page stripe.Page) ([]*stripe.Refund, error)
+}
+
+// PlanLookup resolves the plan an invoice bills for
+type PlanLookup interface {
+	InvoicePlanName(ctx context.Context, invoiceID string) (string, error)
+}
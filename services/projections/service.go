@@ -0,0 +1,197 @@
+package projections
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"apis/payments/services/batching"
+	"apis/payments/services/stripe"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Default and maximum page sizes for charge list queries
+const (
+	defaultListLimit = 50
+	maxListLimit     = 500
+)
+
+// Service maintains the charge list read model from charge, refund and
+// customer events and serves list queries from it
+type Service struct {
+	store     Store
+	charges   ChargeLookup
+	customers CustomerLookup
+	refunds   RefundLookup
+	plans     PlanLookup
+	batches   *batching.Controller
+	tracer    trace.Tracer
+}
+
+// NewService creates a new projection service
+func NewService(store Store, charges ChargeLookup, customers CustomerLookup, refunds RefundLookup, plans PlanLookup) *Service {
+	return &Service{
+		store:     store,
+		charges:   charges,
+		customers: customers,
+		refunds:   refunds,
+		plans:     plans,
+		batches:   batching.NewController(batching.DefaultLimits()),
+		tracer:    otel.Tracer("payments.projections"),
+	}
+}
+
+// UseBatchLimits sets the bounds rebuild batch size and concurrency adapt within
+func (s *Service) UseBatchLimits(limits *batching.Limits) {
+	s.batches = batching.NewController(limits)
+}
+
+// BatchStats returns the rebuild batch size and concurrency as last adapted
+func (s *Service) BatchStats() batching.Stats {
+	return s.batches.Stats()
+}
+
+// ListCharges returns charge list rows, newest first
+func (s *Service) ListCharges(ctx context.Context, filter ChargeFilter) ([]*ChargeRow, error) {
+	ctx, span := s.tracer.Start(ctx, "ListCharges")
+	defer span.End()
+
+	if filter.TenantID == "" {
+		filter.TenantID = DefaultTenantID
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultListLimit
+	}
+	if filter.Limit > maxListLimit {
+		filter.Limit = maxListLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	return s.store.ListChargeRows(ctx, filter)
+}
+
+// ProjectCharge refreshes the row of a charge from its current state
+func (s *Service) ProjectCharge(ctx context.Context, chargeID string) error {
+	ctx, span := s.tracer.Start(ctx, "ProjectCharge")
+	defer span.End()
+
+	charge, err := s.charges.GetCharge(ctx, chargeID)
+	if err != nil {
+		return fmt.Errorf("failed to get charge: %w", err)
+	}
+
+	return s.project(ctx, charge, make(map[string]*stripe.Customer))
+}
+
+// ProjectRefund records the latest refund of a charge on its row
+func (s *Service) ProjectRefund(ctx context.Context, chargeID string) error {
+	ctx, span := s.tracer.Start(ctx, "ProjectRefund")
+	defer span.End()
+
+	refunds, err := s.refunds.ListRefunds(ctx, chargeID, stripe.Page{Limit: 1})
+	if err != nil {
+		return fmt.Errorf("failed to list refunds: %w", err)
+	}
+	if len(refunds) == 0 {
+		return nil
+	}
+
+	latest := refunds[0]
+	for _, refund := range refunds[1:] {
+		if refund.CreatedAt.After(latest.CreatedAt) {
+			latest = refund
+		}
+	}
+
+	return s.store.UpdateChargeRowRefund(ctx, chargeID, latest.ID, latest.Status)
+}
+
+// ProjectCustomer updates the customer details on all of a customer's rows
+func (s *Service) ProjectCustomer(ctx context.Context, customerID, email, name string) error {
+	ctx, span := s.tracer.Start(ctx, "ProjectCustomer")
+	defer span.End()
+
+	return s.store.UpdateChargeRowsCustomer(ctx, customerID, email, name)
+}
+
+// Rebuild reprojects every charge, for backfilling the read model or
+// recovering from missed events
+func (s *Service) Rebuild(ctx context.Context) (int, error) {
+	ctx, span := s.tracer.Start(ctx, "Rebuild")
+	defer span.End()
+
+	charges, err := s.charges.ListCharges(ctx, "", stripe.Page{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list charges: %w", err)
+	}
+
+	// Batches adapt to store and provider latency; each resolves its own customers
+	return batching.Process(ctx, s.batches, charges, func(ctx context.Context, batch []*stripe.Charge) (int, error) {
+		customers := make(map[string]*stripe.Customer)
+		for _, charge := range batch {
+			if err := s.project(ctx, charge, customers); err != nil {
+				return 0, err
+			}
+			if charge.AmountRefunded > 0 {
+				if err := s.ProjectRefund(ctx, charge.ID); err != nil {
+					return 0, err
+				}
+			}
+		}
+		return 0, nil
+	})
+}
+
+// project writes the row for a charge, resolving its customer and plan
+func (s *Service) project(ctx context.Context, charge *stripe.Charge, customers map[string]*stripe.Customer) error {
+	row := &ChargeRow{
+		ChargeID:       charge.ID,
+		TenantID:       DefaultTenantID,
+		CustomerID:     charge.CustomerID,
+		Amount:         charge.Amount,
+		AmountRefunded: charge.AmountRefunded,
+		Currency:       charge.Currency,
+		Status:         charge.Status,
+		Description:    charge.Description,
+		ChargeCreated:  time.Unix(charge.Created, 0),
+	}
+	if tenantID := charge.Metadata["tenant_id"]; tenantID != "" {
+		row.TenantID = tenantID
+	}
+
+	if charge.CustomerID != "" {
+		customer, ok := customers[charge.CustomerID]
+		if !ok {
+			var err error
+			customer, err = s.customers.GetCustomer(ctx, charge.CustomerID)
+			if err != nil {
+				// Deleted customers keep their charges; the row is still useful without them
+				log.Printf("Failed to get customer %s for charge %s: %v", charge.CustomerID, charge.ID, err)
+			}
+			customers[charge.CustomerID] = customer
+		}
+		if customer != nil {
+			row.CustomerEmail = customer.Email
+			row.CustomerName = customer.Name
+		}
+	}
+
+	if charge.InvoiceID != "" {
+		planName, err := s.plans.InvoicePlanName(ctx, charge.InvoiceID)
+		if err != nil {
+			return fmt.Errorf("failed to get plan for charge %s: %w", charge.ID, err)
+		}
+		row.PlanName = planName
+	}
+
+	if err := s.store.UpsertChargeRow(ctx, row); err != nil {
+		return fmt.Errorf("failed to project charge %s: %w", charge.ID, err)
+	}
+
+	return nil
+}
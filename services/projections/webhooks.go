@@ -0,0 +1,66 @@
+package projections
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"apis/payments/services/stripe"
+
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// chargeEvents are the charge events that change a charge list row
+var chargeEvents = []stripego.EventType{
+	stripego.EventTypeChargeSucceeded,
+	stripego.EventTypeChargeFailed,
+	stripego.EventTypeChargePending,
+	stripego.EventTypeChargeCaptured,
+	stripego.EventTypeChargeExpired,
+	stripego.EventTypeChargeUpdated,
+	stripego.EventTypeChargeRefunded,
+}
+
+// RegisterWebhookHandlers keeps the read model up to date from charge,
+// refund and customer events
+func (s *Service) RegisterWebhookHandlers(webhooks *stripe.WebhookService) {
+	for _, eventType := range chargeEvents {
+		webhooks.On(eventType, func(ctx context.Context, event stripego.Event) error {
+			var charge stripego.Charge
+			if err := json.Unmarshal(event.Data.Raw, &charge); err != nil {
+				return fmt.Errorf("failed to parse charge: %w", err)
+			}
+
+			if err := s.ProjectCharge(ctx, charge.ID); err != nil {
+				return err
+			}
+
+			if event.Type == stripego.EventTypeChargeRefunded {
+				return s.ProjectRefund(ctx, charge.ID)
+			}
+			return nil
+		})
+	}
+
+	webhooks.On(stripego.EventTypeChargeRefundUpdated, func(ctx context.Context, event stripego.Event) error {
+		var refund stripego.Refund
+		if err := json.Unmarshal(event.Data.Raw, &refund); err != nil {
+			return fmt.Errorf("failed to parse refund: %w", err)
+		}
+
+		if refund.Charge == nil || refund.Charge.ID == "" {
+			return nil
+		}
+
+		return s.ProjectRefund(ctx, refund.Charge.ID)
+	})
+
+	webhooks.On(stripego.EventTypeCustomerUpdated, func(ctx context.Context, event stripego.Event) error {
+		var customer stripego.Customer
+		if err := json.Unmarshal(event.Data.Raw, &customer); err != nil {
+			return fmt.Errorf("failed to parse customer: %w", err)
+		}
+
+		return s.ProjectCustomer(ctx, customer.ID, customer.Email, customer.Name)
+	})
+}
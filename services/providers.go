@@ -0,0 +1,84 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ProviderHeader names the provider a request is served by, overriding the
+// tenant's default provider and routing rules
+const ProviderHeader = "X-Payment-Provider"
+
+// ErrProviderNotEnabled is returned when a request names a provider this
+// deployment does not serve
+var ErrProviderNotEnabled = errors.New("payment provider is not enabled")
+
+// EnabledProviders are the providers requests may select
+type EnabledProviders struct {
+	names map[string]bool
+}
+
+// NewEnabledProviders creates the set of selectable providers
+func NewEnabledProviders(names []string) *EnabledProviders {
+	p := &EnabledProviders{names: make(map[string]bool, len(names))}
+	for _, name := range names {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			p.names[name] = true
+		}
+	}
+	return p
+}
+
+// LoadEnabledProviders loads the selectable providers from PAYMENT_PROVIDERS,
+// a comma-separated list of registered providers, e.g. stripe,paddle. Every
+// registered provider is selectable when it is unset; unregistered ones are
+// logged and ignored.
+func LoadEnabledProviders() *EnabledProviders {
+	supported := GetFactory().GetSupportedProviders()
+	value := os.Getenv("PAYMENT_PROVIDERS")
+	if value == "" {
+		return NewEnabledProviders(supported)
+	}
+
+	registered := NewEnabledProviders(supported)
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !registered.names[name] {
+			log.Printf("Warning: ignoring unsupported provider %q in PAYMENT_PROVIDERS", name)
+			continue
+		}
+		names = append(names, name)
+	}
+	return NewEnabledProviders(names)
+}
+
+// Resolve validates the provider a request names, returning it normalized,
+// or an empty string when the request names none
+func (p *EnabledProviders) Resolve(requested string) (string, error) {
+	provider := strings.ToLower(strings.TrimSpace(requested))
+	if provider == "" {
+		return "", nil
+	}
+	if !p.names[provider] {
+		return "", fmt.Errorf("%w: %s", ErrProviderNotEnabled, provider)
+	}
+	return provider, nil
+}
+
+// List returns the selectable providers by name
+func (p *EnabledProviders) List() []string {
+	names := make([]string, 0, len(p.names))
+	for name := range p.names {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
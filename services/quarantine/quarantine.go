@@ -0,0 +1,137 @@
+package quarantine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Subjects a quarantine can apply to
+const (
+	SubjectAPIKey = "api_key"
+	SubjectTenant = "tenant"
+)
+
+// Held mutation statuses
+const (
+	MutationPending  = "pending"
+	MutationReleased = "released"
+	MutationRejected = "rejected"
+	MutationFailed   = "failed"
+)
+
+// Errors returned by the quarantine service
+var (
+	ErrAlreadyQuarantined = errors.New("subject is already quarantined")
+	ErrNotQuarantined     = errors.New("quarantine not found or already lifted")
+	ErrNotPending         = errors.New("held mutation not found or already decided")
+	ErrInvalidQuarantine  = errors.New("invalid quarantine")
+)
+
+// Quarantine restricts an API key or tenant suspected of credential
+// compromise. Reads still work; mutations are held for release.
+type Quarantine struct {
+	ID          string     `json:"id"`
+	SubjectType string     `json:"subject_type"`
+	SubjectID   string     `json:"subject_id"`
+	Reason      string     `json:"reason"`
+	CreatedBy   string     `json:"created_by"`
+	LiftedBy    string     `json:"lifted_by,omitempty"`
+	LiftedAt    *time.Time `json:"lifted_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// Validate checks the subject of a new quarantine
+func (q *Quarantine) Validate() error {
+	if q.SubjectType != SubjectAPIKey && q.SubjectType != SubjectTenant {
+		return fmt.Errorf("%w: subject type must be api_key or tenant", ErrInvalidQuarantine)
+	}
+	if q.SubjectID == "" {
+		return fmt.Errorf("%w: subject ID cannot be empty", ErrInvalidQuarantine)
+	}
+	return nil
+}
+
+// Caller identifies who a request acts for
+type Caller struct {
+	TenantID   string `json:"tenant_id"`
+	APIKeyID   string `json:"api_key_id"`
+	OperatorID string `json:"operator_id"`
+}
+
+// Request is an API request made by a quarantined caller
+type Request struct {
+	Method      string
+	Path        string
+	ContentType string
+	Body        []byte
+}
+
+// IsMutation reports whether the request changes state
+func (r *Request) IsMutation() bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	default:
+		return true
+	}
+}
+
+// Activity is a flagged request made by a quarantined caller
+type Activity struct {
+	ID             string    `json:"id"`
+	QuarantineID   string    `json:"quarantine_id"`
+	TenantID       string    `json:"tenant_id"`
+	APIKeyID       string    `json:"api_key_id"`
+	Method         string    `json:"method"`
+	Path           string    `json:"path"`
+	HeldMutationID string    `json:"held_mutation_id,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// HeldMutation is a mutation accepted from a quarantined caller and held
+// until an administrator releases or rejects it. Once released, the
+// response the request produced is kept with it.
+type HeldMutation struct {
+	ID             string     `json:"id"`
+	QuarantineID   string     `json:"quarantine_id"`
+	TenantID       string     `json:"tenant_id"`
+	APIKeyID       string     `json:"api_key_id"`
+	OperatorID     string     `json:"operator_id"`
+	Method         string     `json:"method"`
+	Path           string     `json:"path"`
+	ContentType    string     `json:"content_type"`
+	Body           string     `json:"body"`
+	Status         string     `json:"status"`
+	DecidedBy      string     `json:"decided_by,omitempty"`
+	DecidedAt      *time.Time `json:"decided_at,omitempty"`
+	ResponseStatus int        `json:"response_status,omitempty"`
+	ResponseBody   string     `json:"response_body,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// Store persists quarantines, flagged activity and held mutations
+type Store interface {
+	CreateQuarantine(ctx context.Context, quarantine *Quarantine) (*Quarantine, error)
+	GetQuarantine(ctx context.Context, id string) (*Quarantine, error)
+	GetActiveQuarantine(ctx context.Context, subjectType, subjectID string) (*Quarantine, error)
+	ListActiveQuarantines(ctx context.Context) ([]*Quarantine, error)
+	LiftQuarantine(ctx context.Context, id, liftedBy string) (*Quarantine, error)
+	RecordQuarantineActivity(ctx context.Context, activity *Activity) error
+	ListQuarantineActivity(ctx context.Context, quarantineID string, limit int) ([]*Activity, error)
+	CreateHeldMutation(ctx context.Context, mutation *HeldMutation) (*HeldMutation, error)
+	GetHeldMutation(ctx context.Context, id string) (*HeldMutation, error)
+	ListHeldMutations(ctx context.Context, status string) ([]*HeldMutation, error)
+	// DecideHeldMutation returns sql.ErrNoRows if the mutation is not pending
+	DecideHeldMutation(ctx context.Context, id, status, decidedBy string) (*HeldMutation, error)
+	RecordHeldMutationResult(ctx context.Context, id, status string, responseStatus int, responseBody string) (*HeldMutation, error)
+}
+
+// Replayer executes a released mutation as the caller that made it,
+// returning the response it produced
+type Replayer interface {
+	Replay(ctx context.Context, mutation *HeldMutation) (status int, body []byte, err error)
+}
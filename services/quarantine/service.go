@@ -0,0 +1,281 @@
+package quarantine
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DefaultActivityLimit bounds activity listings that give no limit
+const DefaultActivityLimit = 100
+
+// Service quarantines API keys and tenants suspected of credential
+// compromise without cutting the merchant off: reads are served, mutations
+// are held for an administrator, and every request is flagged.
+type Service struct {
+	store    Store
+	replayer Replayer
+	tracer   trace.Tracer
+}
+
+// NewService creates a new quarantine service
+func NewService(store Store, replayer Replayer) *Service {
+	return &Service{
+		store:    store,
+		replayer: replayer,
+		tracer:   otel.Tracer("payments.quarantine"),
+	}
+}
+
+// Quarantine places an API key or tenant into quarantine
+func (s *Service) Quarantine(ctx context.Context, quarantine *Quarantine) (*Quarantine, error) {
+	ctx, span := s.tracer.Start(ctx, "Quarantine")
+	defer span.End()
+
+	if err := quarantine.Validate(); err != nil {
+		return nil, err
+	}
+
+	_, err := s.store.GetActiveQuarantine(ctx, quarantine.SubjectType, quarantine.SubjectID)
+	if err == nil {
+		return nil, ErrAlreadyQuarantined
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	quarantine.ID = fmt.Sprintf("qtn_%s", uuid.New().String())
+	created, err := s.store.CreateQuarantine(ctx, quarantine)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Quarantined %s %s by %s: %s", created.SubjectType, created.SubjectID, created.CreatedBy, created.Reason)
+	return created, nil
+}
+
+// Lift ends a quarantine. Mutations already held stay held until released
+// or rejected.
+func (s *Service) Lift(ctx context.Context, quarantineID, operatorID string) (*Quarantine, error) {
+	ctx, span := s.tracer.Start(ctx, "Lift")
+	defer span.End()
+
+	if operatorID == "" {
+		return nil, fmt.Errorf("operator ID cannot be empty")
+	}
+
+	lifted, err := s.store.LiftQuarantine(ctx, quarantineID, operatorID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotQuarantined
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Quarantine %s on %s %s lifted by %s", lifted.ID, lifted.SubjectType, lifted.SubjectID, operatorID)
+	return lifted, nil
+}
+
+// Get retrieves a quarantine
+func (s *Service) Get(ctx context.Context, quarantineID string) (*Quarantine, error) {
+	ctx, span := s.tracer.Start(ctx, "Get")
+	defer span.End()
+
+	quarantine, err := s.store.GetQuarantine(ctx, quarantineID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotQuarantined
+	}
+
+	return quarantine, err
+}
+
+// ListActive lists quarantines that have not been lifted
+func (s *Service) ListActive(ctx context.Context) ([]*Quarantine, error) {
+	ctx, span := s.tracer.Start(ctx, "ListActive")
+	defer span.End()
+
+	return s.store.ListActiveQuarantines(ctx)
+}
+
+// ListActivity lists the flagged requests made under a quarantine, newest first
+func (s *Service) ListActivity(ctx context.Context, quarantineID string, limit int) ([]*Activity, error) {
+	ctx, span := s.tracer.Start(ctx, "ListActivity")
+	defer span.End()
+
+	if limit <= 0 {
+		limit = DefaultActivityLimit
+	}
+
+	return s.store.ListQuarantineActivity(ctx, quarantineID, limit)
+}
+
+// Check returns the active quarantine covering a caller, or nil if there is
+// none. A quarantined API key takes precedence over its tenant.
+func (s *Service) Check(ctx context.Context, caller Caller) (*Quarantine, error) {
+	ctx, span := s.tracer.Start(ctx, "Check")
+	defer span.End()
+
+	subjects := []struct{ kind, id string }{
+		{SubjectAPIKey, caller.APIKeyID},
+		{SubjectTenant, caller.TenantID},
+	}
+	for _, subject := range subjects {
+		if subject.id == "" {
+			continue
+		}
+
+		quarantine, err := s.store.GetActiveQuarantine(ctx, subject.kind, subject.id)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to check quarantine: %w", err)
+		}
+		return quarantine, nil
+	}
+
+	return nil, nil
+}
+
+// Flag records a request made by a quarantined caller. Mutations are held
+// and returned; reads return nil and go ahead.
+func (s *Service) Flag(ctx context.Context, quarantine *Quarantine, caller Caller, request *Request) (*HeldMutation, error) {
+	ctx, span := s.tracer.Start(ctx, "Flag")
+	defer span.End()
+
+	activity := newActivity(quarantine, caller, request)
+
+	var held *HeldMutation
+	if request.IsMutation() {
+		var err error
+		held, err = s.store.CreateHeldMutation(ctx, &HeldMutation{
+			ID:           fmt.Sprintf("hmut_%s", uuid.New().String()),
+			QuarantineID: quarantine.ID,
+			TenantID:     caller.TenantID,
+			APIKeyID:     caller.APIKeyID,
+			OperatorID:   caller.OperatorID,
+			Method:       request.Method,
+			Path:         request.Path,
+			ContentType:  request.ContentType,
+			Body:         string(request.Body),
+			Status:       MutationPending,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to hold mutation: %w", err)
+		}
+		activity.HeldMutationID = held.ID
+	}
+
+	if err := s.store.RecordQuarantineActivity(ctx, activity); err != nil {
+		// The mutation is already held, so losing the activity row must not lose the request
+		log.Printf("Failed to record quarantine activity for %s: %v", quarantine.ID, err)
+	}
+
+	log.Printf("Quarantined %s %s: %s %s", quarantine.SubjectType, quarantine.SubjectID, request.Method, request.Path)
+	return held, nil
+}
+
+// Refuse records a mutation by a quarantined caller that cannot be held,
+// such as one made over a transport that cannot be replayed. The caller
+// refuses the mutation instead of running it.
+func (s *Service) Refuse(ctx context.Context, quarantine *Quarantine, caller Caller, request *Request) error {
+	ctx, span := s.tracer.Start(ctx, "Refuse")
+	defer span.End()
+
+	if err := s.store.RecordQuarantineActivity(ctx, newActivity(quarantine, caller, request)); err != nil {
+		return fmt.Errorf("failed to record quarantine activity: %w", err)
+	}
+
+	log.Printf("Quarantined %s %s, refused: %s %s", quarantine.SubjectType, quarantine.SubjectID, request.Method, request.Path)
+	return nil
+}
+
+// GetHeld retrieves a held mutation
+func (s *Service) GetHeld(ctx context.Context, mutationID string) (*HeldMutation, error) {
+	ctx, span := s.tracer.Start(ctx, "GetHeld")
+	defer span.End()
+
+	return s.store.GetHeldMutation(ctx, mutationID)
+}
+
+// ListHeld lists held mutations, optionally in one status, oldest first
+func (s *Service) ListHeld(ctx context.Context, status string) ([]*HeldMutation, error) {
+	ctx, span := s.tracer.Start(ctx, "ListHeld")
+	defer span.End()
+
+	return s.store.ListHeldMutations(ctx, status)
+}
+
+// Release executes a held mutation as the caller that made it. The mutation
+// is marked released before it runs, so it runs at most once; a replay that
+// cannot be carried out is marked failed and keeps the error.
+func (s *Service) Release(ctx context.Context, mutationID, operatorID string) (*HeldMutation, error) {
+	ctx, span := s.tracer.Start(ctx, "Release")
+	defer span.End()
+
+	mutation, err := s.decide(ctx, mutationID, MutationReleased, operatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	status, body, err := s.replayer.Replay(ctx, mutation)
+	if err != nil {
+		log.Printf("Failed to replay held mutation %s: %v", mutation.ID, err)
+		return s.store.RecordHeldMutationResult(ctx, mutation.ID, MutationFailed, 0, err.Error())
+	}
+
+	log.Printf("Held mutation %s released by %s: %s %s returned %d", mutation.ID, operatorID, mutation.Method, mutation.Path, status)
+	return s.store.RecordHeldMutationResult(ctx, mutation.ID, MutationReleased, status, string(body))
+}
+
+// Reject discards a held mutation without executing it
+func (s *Service) Reject(ctx context.Context, mutationID, operatorID string) (*HeldMutation, error) {
+	ctx, span := s.tracer.Start(ctx, "Reject")
+	defer span.End()
+
+	mutation, err := s.decide(ctx, mutationID, MutationRejected, operatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Held mutation %s rejected by %s", mutation.ID, operatorID)
+	return mutation, nil
+}
+
+// newActivity describes a request made under a quarantine
+func newActivity(quarantine *Quarantine, caller Caller, request *Request) *Activity {
+	return &Activity{
+		ID:           fmt.Sprintf("qact_%s", uuid.New().String()),
+		QuarantineID: quarantine.ID,
+		TenantID:     caller.TenantID,
+		APIKeyID:     caller.APIKeyID,
+		Method:       request.Method,
+		Path:         request.Path,
+	}
+}
+
+// decide moves a pending mutation to status
+func (s *Service) decide(ctx context.Context, mutationID, status, operatorID string) (*HeldMutation, error) {
+	if mutationID == "" {
+		return nil, fmt.Errorf("mutation ID cannot be empty")
+	}
+	if operatorID == "" {
+		return nil, fmt.Errorf("operator ID cannot be empty")
+	}
+
+	mutation, err := s.store.DecideHeldMutation(ctx, mutationID, status, operatorID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotPending
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return mutation, nil
+}
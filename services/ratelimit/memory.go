@@ -0,0 +1,48 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// bucket is a token bucket as of its last refill
+type bucket struct {
+	tokens   float64
+	refilled time.Time
+}
+
+// MemoryStore keeps token buckets in this instance's memory, so each
+// instance limits requests on its own
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket)}
+}
+
+// Take refills a bucket as of now and takes a token if one is left
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (bool, float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), refilled: now}
+		s.buckets[key] = b
+	}
+
+	if elapsed := now.Sub(b.refilled); elapsed > 0 {
+		b.tokens = min(float64(limit.Burst), b.tokens+elapsed.Seconds()*limit.Rate)
+		b.refilled = now
+	}
+
+	if b.tokens < 1 {
+		return false, b.tokens, nil
+	}
+	b.tokens--
+	return true, b.tokens, nil
+}
@@ -0,0 +1,166 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Backends buckets can be kept in
+const (
+	BackendMemory = "memory"
+	BackendRedis  = "redis"
+)
+
+// DefaultEndpoint names the bucket of requests no rule matches
+const DefaultEndpoint = "default"
+
+// defaultLimit applies to requests no rule matches unless configured
+var defaultLimit = Limit{Rate: 20, Burst: 40}
+
+// ErrRateLimited is returned for requests over their limit
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// Limit is a token bucket refilled at Rate tokens per second, holding at
+// most Burst tokens. Each request takes one token.
+type Limit struct {
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+}
+
+// Rule limits the requests to an endpoint. Path segments may be "*", and
+// paths below Path match too, so "POST /charges" covers captures.
+type Rule struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Limit
+}
+
+// Endpoint names the rule's bucket
+func (r Rule) Endpoint() string {
+	return r.Method + " " + r.Path
+}
+
+// matches reports whether the rule covers a request
+func (r Rule) matches(method, path string) bool {
+	if r.Method != "*" && !strings.EqualFold(r.Method, method) {
+		return false
+	}
+
+	patternSegments := strings.Split(strings.Trim(r.Path, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(pathSegments) < len(patternSegments) {
+		return false
+	}
+	for i, segment := range patternSegments {
+		if segment != "*" && segment != pathSegments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Config controls how requests are limited
+type Config struct {
+	Enabled bool
+	Backend string // BackendMemory, or BackendRedis to share buckets between instances
+	Default Limit  // Limit of requests no rule matches
+	Rules   []Rule // Per-endpoint limits; the first matching rule applies
+}
+
+// LoadConfig loads the rate limiting configuration from environment
+// variables. RATE_LIMIT_ENDPOINTS lists per-endpoint limits as
+// "METHOD /path=rate:burst" separated by commas.
+func LoadConfig() (*Config, error) {
+	config := &Config{
+		Enabled: true,
+		Backend: BackendMemory,
+		Default: defaultLimit,
+	}
+
+	if enabled, err := strconv.ParseBool(os.Getenv("RATE_LIMIT_ENABLED")); err == nil {
+		config.Enabled = enabled
+	}
+	if backend := os.Getenv("RATE_LIMIT_BACKEND"); backend != "" {
+		if backend != BackendMemory && backend != BackendRedis {
+			return nil, fmt.Errorf("unknown rate limit backend %q", backend)
+		}
+		config.Backend = backend
+	}
+	if rate, err := strconv.ParseFloat(os.Getenv("RATE_LIMIT_RATE"), 64); err == nil && rate > 0 {
+		config.Default.Rate = rate
+	}
+	if burst, err := strconv.Atoi(os.Getenv("RATE_LIMIT_BURST")); err == nil && burst > 0 {
+		config.Default.Burst = burst
+	}
+
+	rules, err := ParseRules(os.Getenv("RATE_LIMIT_ENDPOINTS"))
+	if err != nil {
+		return nil, err
+	}
+	config.Rules = rules
+
+	return config, nil
+}
+
+// ParseRules parses per-endpoint limits such as
+// "POST /charges=5:10,POST /refunds=1:5"
+func ParseRules(raw string) ([]Rule, error) {
+	var rules []Rule
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		endpoint, limit, ok := strings.Cut(entry, "=")
+		method, path, hasPath := strings.Cut(strings.TrimSpace(endpoint), " ")
+		rate, burst, hasBurst := strings.Cut(limit, ":")
+		if !ok || !hasPath || !hasBurst {
+			return nil, fmt.Errorf("invalid rate limit %q: want \"METHOD /path=rate:burst\"", entry)
+		}
+
+		rule := Rule{Method: strings.ToUpper(method), Path: strings.TrimSpace(path)}
+		var err error
+		if rule.Rate, err = strconv.ParseFloat(rate, 64); err != nil || rule.Rate <= 0 {
+			return nil, fmt.Errorf("invalid rate limit %q: rate must be a positive number", entry)
+		}
+		if rule.Burst, err = strconv.Atoi(burst); err != nil || rule.Burst <= 0 {
+			return nil, fmt.Errorf("invalid rate limit %q: burst must be a positive integer", entry)
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// Decision is the outcome of taking a token for a request
+type Decision struct {
+	Allowed    bool
+	Endpoint   string
+	Limit      int           // Burst of the bucket
+	Remaining  int           // Whole tokens left
+	Reset      time.Duration // Until the bucket is full again
+	RetryAfter time.Duration // Until a denied request would be allowed
+}
+
+// Usage counts a caller's requests to an endpoint since the instance started
+type Usage struct {
+	Subject       string     `json:"subject"`
+	Endpoint      string     `json:"endpoint"`
+	Allowed       int64      `json:"allowed"`
+	Limited       int64      `json:"limited"`
+	LastRequestAt time.Time  `json:"last_request_at"`
+	LastLimitedAt *time.Time `json:"last_limited_at,omitempty"`
+}
+
+// Store keeps token buckets
+type Store interface {
+	// Take refills a bucket as of now and takes a token if one is left. It
+	// returns whether a token was taken and the tokens left.
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (bool, float64, error)
+}
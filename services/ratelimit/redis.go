@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"apis/payments/services/redis"
+)
+
+// takeScript refills and takes from a bucket atomically. Tokens are returned
+// as a string because Redis truncates Lua numbers to integers.
+const takeScript = `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'refilled')
+local tokens = tonumber(state[1]) or burst
+local refilled = tonumber(state[2]) or now
+if now > refilled then
+  tokens = math.min(burst, tokens + (now - refilled) / 1000 * rate)
+  refilled = now
+end
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'refilled', refilled)
+redis.call('PEXPIRE', KEYS[1], ARGV[4])
+return {allowed, tostring(tokens)}
+`
+
+// RedisStore keeps token buckets in Redis, so every instance draws from the
+// same buckets
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore creates a store over a Redis client
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+// Take refills a bucket as of now and takes a token if one is left
+func (s *RedisStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (bool, float64, error) {
+	// Idle buckets are full again once they have refilled, so they can expire
+	ttl := time.Duration(math.Ceil(float64(limit.Burst)/limit.Rate*1000))*time.Millisecond + time.Second
+
+	result, err := s.client.Eval(ctx, takeScript, []string{"ratelimit:" + key},
+		strconv.FormatFloat(limit.Rate, 'f', -1, 64),
+		strconv.Itoa(limit.Burst),
+		strconv.FormatInt(now.UnixMilli(), 10),
+		strconv.FormatInt(ttl.Milliseconds(), 10),
+	).Slice()
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to take rate limit token: %w", err)
+	}
+
+	if len(result) != 2 {
+		return false, 0, fmt.Errorf("unexpected rate limit reply %v", result)
+	}
+	tokens, ok := result[1].(string)
+	if !ok {
+		return false, 0, fmt.Errorf("unexpected rate limit tokens %v", result[1])
+	}
+	remaining, err := strconv.ParseFloat(tokens, 64)
+	if err != nil {
+		return false, 0, fmt.Errorf("unexpected rate limit tokens %q", tokens)
+	}
+
+	return result[0] == int64(1), remaining, nil
+}
@@ -0,0 +1,147 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Service limits each caller's requests with a token bucket per endpoint and
+// counts how much of its limits it uses
+type Service struct {
+	store  Store
+	config atomic.Pointer[Config]
+	tracer trace.Tracer
+
+	mu    sync.Mutex
+	usage map[string]*Usage
+}
+
+// NewService creates a new rate limiting service
+func NewService(store Store, config *Config) *Service {
+	if config == nil {
+		config = &Config{Enabled: true, Backend: BackendMemory, Default: defaultLimit}
+	}
+
+	s := &Service{
+		store:  store,
+		tracer: otel.Tracer("payments.ratelimit"),
+		usage:  make(map[string]*Usage),
+	}
+	s.config.Store(config)
+	return s
+}
+
+// Reconfigure replaces the limits. Buckets keep their tokens; the store they
+// are kept in is chosen at startup.
+func (s *Service) Reconfigure(config *Config) {
+	s.config.Store(config)
+}
+
+// Enabled reports whether requests are limited
+func (s *Service) Enabled() bool {
+	return s.config.Load().Enabled
+}
+
+// Rules returns the per-endpoint limits and the limit of every other request
+func (s *Service) Rules() ([]Rule, Limit) {
+	config := s.config.Load()
+	return config.Rules, config.Default
+}
+
+// Allow takes a token from the subject's bucket for an endpoint. Denied
+// requests take nothing, so retrying after RetryAfter succeeds.
+func (s *Service) Allow(ctx context.Context, subject, method, path string) (*Decision, error) {
+	ctx, span := s.tracer.Start(ctx, "Allow")
+	defer span.End()
+
+	endpoint, limit := s.limit(method, path)
+	now := time.Now()
+
+	allowed, tokens, err := s.store.Take(ctx, subject+"|"+endpoint, limit, now)
+	if err != nil {
+		return nil, err
+	}
+
+	decision := &Decision{
+		Allowed:   allowed,
+		Endpoint:  endpoint,
+		Limit:     limit.Burst,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     seconds((float64(limit.Burst) - tokens) / limit.Rate),
+	}
+	if !allowed {
+		decision.RetryAfter = seconds((1 - tokens) / limit.Rate)
+	}
+	s.record(subject, endpoint, allowed, now)
+
+	return decision, nil
+}
+
+// Usage returns the requests counted for every subject and endpoint, or for
+// one subject
+func (s *Service) Usage(subject string) []*Usage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	usage := make([]*Usage, 0, len(s.usage))
+	for _, counted := range s.usage {
+		if subject != "" && counted.Subject != subject {
+			continue
+		}
+		copied := *counted
+		usage = append(usage, &copied)
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Subject != usage[j].Subject {
+			return usage[i].Subject < usage[j].Subject
+		}
+		return usage[i].Endpoint < usage[j].Endpoint
+	})
+
+	return usage
+}
+
+// limit returns the bucket and limit of a request
+func (s *Service) limit(method, path string) (string, Limit) {
+	config := s.config.Load()
+	for _, rule := range config.Rules {
+		if rule.matches(method, path) {
+			return rule.Endpoint(), rule.Limit
+		}
+	}
+	return DefaultEndpoint, config.Default
+}
+
+// record counts a request
+func (s *Service) record(subject, endpoint string, allowed bool, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := subject + "|" + endpoint
+	counted, ok := s.usage[key]
+	if !ok {
+		counted = &Usage{Subject: subject, Endpoint: endpoint}
+		s.usage[key] = counted
+	}
+
+	counted.LastRequestAt = now
+	if allowed {
+		counted.Allowed++
+		return
+	}
+	counted.Limited++
+	limitedAt := now
+	counted.LastLimitedAt = &limitedAt
+}
+
+// seconds converts a non-negative number of seconds to a duration
+func seconds(value float64) time.Duration {
+	return time.Duration(max(value, 0) * float64(time.Second))
+}
@@ -0,0 +1,90 @@
+package receipts
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ErrInvalidDescriptor is returned for statement descriptors a provider
+// would reject
+var ErrInvalidDescriptor = errors.New("invalid statement descriptor")
+
+// DescriptorRules are a provider's limits on what customers see on their
+// card statements. A full descriptor replaces the account's; a suffix is
+// appended to the account's shortened descriptor.
+type DescriptorRules struct {
+	Full            bool // Charges may set a full descriptor
+	MinLength       int  // Shortest full descriptor
+	MaxLength       int  // Longest full descriptor
+	Suffix          bool // Charges may set a suffix
+	MaxSuffixLength int
+}
+
+// descriptorRules are the rules of each provider that accepts descriptors
+var descriptorRules = map[string]DescriptorRules{
+	"stripe":    {Full: true, MinLength: 5, MaxLength: 22, Suffix: true, MaxSuffixLength: 22},
+	"simulator": {Full: true, MinLength: 5, MaxLength: 22, Suffix: true, MaxSuffixLength: 22},
+	// PayPal's soft descriptor and Square's statement description identifier
+	// follow the merchant's name
+	"paypal": {Suffix: true, MaxSuffixLength: 22},
+	"square": {Suffix: true, MaxSuffixLength: 20},
+}
+
+// forbiddenDescriptorChars are rejected by card networks
+const forbiddenDescriptorChars = `<>\'"*`
+
+// Rules returns a provider's descriptor rules. Providers without rules
+// accept no descriptors.
+func Rules(provider string) DescriptorRules {
+	return descriptorRules[provider]
+}
+
+// ValidateDescriptor checks a charge's statement descriptor and suffix, either
+// of which may be empty, against a provider's rules
+func ValidateDescriptor(provider, descriptor, suffix string) error {
+	rules := Rules(provider)
+
+	if descriptor != "" {
+		if !rules.Full {
+			return fmt.Errorf("%w: %s does not accept statement_descriptor", ErrInvalidDescriptor, provider)
+		}
+		if err := checkDescriptor("statement_descriptor", descriptor, rules.MinLength, rules.MaxLength); err != nil {
+			return err
+		}
+	}
+	if suffix != "" {
+		if !rules.Suffix {
+			return fmt.Errorf("%w: %s does not accept statement_descriptor_suffix", ErrInvalidDescriptor, provider)
+		}
+		if err := checkDescriptor("statement_descriptor_suffix", suffix, 1, rules.MaxSuffixLength); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkDescriptor checks a descriptor's length and characters. Statements
+// print Latin characters only, and must show at least one letter.
+func checkDescriptor(field, value string, minLength, maxLength int) error {
+	if length := len([]rune(value)); length < minLength || length > maxLength {
+		return fmt.Errorf("%w: %s must be %d to %d characters", ErrInvalidDescriptor, field, minLength, maxLength)
+	}
+
+	letters := 0
+	for _, r := range value {
+		if r > unicode.MaxASCII || !unicode.IsPrint(r) || strings.ContainsRune(forbiddenDescriptorChars, r) {
+			return fmt.Errorf("%w: %s may not contain %q", ErrInvalidDescriptor, field, r)
+		}
+		if unicode.IsLetter(r) {
+			letters++
+		}
+	}
+	if letters == 0 {
+		return fmt.Errorf("%w: %s must contain a letter", ErrInvalidDescriptor, field)
+	}
+
+	return nil
+}
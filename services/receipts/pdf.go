@@ -0,0 +1,45 @@
+package receipts
+
+import (
+	"apis/payments/services/documents"
+)
+
+// RenderPDF lays a receipt out as a one-page A4 PDF in Helvetica
+func RenderPDF(receipt *Receipt) []byte {
+	pdf := documents.NewPDF()
+	y := 780
+	line := func(size int, text string) {
+		pdf.Text(documents.FontRegular, size, 56, y, text)
+		y -= size + 10
+	}
+	row := func(label, value string) {
+		if value == "" {
+			return
+		}
+		pdf.Text(documents.FontRegular, 11, 56, y, label)
+		pdf.Text(documents.FontRegular, 11, 220, y, value)
+		y -= 20
+	}
+
+	if receipt.BusinessName != "" {
+		line(18, receipt.BusinessName)
+	}
+	line(14, "Receipt "+receipt.Number)
+	y -= 10
+	row("Amount paid", receipt.Amount)
+	row("Amount refunded", receipt.AmountRefunded)
+	if !receipt.PaidAt.IsZero() {
+		row("Date", receipt.PaidAt.UTC().Format("January 2, 2006 15:04 MST"))
+	}
+	row("Status", receipt.Status)
+	row("Payment method", receipt.PaymentMethod)
+	row("Description", receipt.Description)
+	row("Statement descriptor", receipt.StatementDescriptor)
+	row("Sent to", receipt.ReceiptEmail)
+	if receipt.SupportEmail != "" {
+		y -= 10
+		line(10, "Questions? Contact "+receipt.SupportEmail)
+	}
+
+	return pdf.Bytes()
+}
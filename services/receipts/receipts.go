@@ -0,0 +1,100 @@
+package receipts
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/mail"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrInvalidSettings is returned when saving invalid receipt settings
+var ErrInvalidSettings = errors.New("invalid receipt settings")
+
+// Settings are how a tenant's charges are described and receipted
+type Settings struct {
+	TenantID string `json:"tenant_id"`
+	// SendReceiptEmails has the provider email a receipt to the customer's
+	// address when a charge names no receipt_email
+	SendReceiptEmails bool `json:"send_receipt_emails"`
+	// DescriptorSuffix is the statement_descriptor_suffix of charges that
+	// set no descriptor
+	DescriptorSuffix string `json:"descriptor_suffix,omitempty"`
+	// BusinessName and SupportEmail head locally generated receipts
+	BusinessName string    `json:"business_name,omitempty"`
+	SupportEmail string    `json:"support_email,omitempty"`
+	CreatedAt    time.Time `json:"created_at,omitempty"`
+	UpdatedAt    time.Time `json:"updated_at,omitempty"`
+}
+
+// Receipt is what a locally generated receipt shows about a charge
+type Receipt struct {
+	Number              string // Identifies the receipt, e.g. the charge ID
+	BusinessName        string
+	SupportEmail        string
+	Description         string
+	Amount              string // Formatted in the charge's currency, e.g. "$12.50"
+	AmountRefunded      string // Empty when nothing was refunded
+	Status              string
+	PaymentMethod       string // e.g. "visa"
+	StatementDescriptor string
+	ReceiptEmail        string
+	PaidAt              time.Time
+}
+
+// Store persists receipt settings. Tenants without settings return
+// sql.ErrNoRows.
+type Store interface {
+	GetReceiptSettings(ctx context.Context, tenantID string) (*Settings, error)
+	UpsertReceiptSettings(ctx context.Context, settings *Settings) (*Settings, error)
+}
+
+// Service manages tenants' receipt settings
+type Service struct {
+	store  Store
+	tracer trace.Tracer
+}
+
+// NewService creates a new receipt service
+func NewService(store Store) *Service {
+	return &Service{
+		store:  store,
+		tracer: otel.Tracer("payments.receipts"),
+	}
+}
+
+// Settings returns a tenant's receipt settings, or the defaults when it has
+// saved none: no receipt emails and no descriptor suffix
+func (s *Service) Settings(ctx context.Context, tenantID string) (*Settings, error) {
+	ctx, span := s.tracer.Start(ctx, "Settings")
+	defer span.End()
+
+	settings, err := s.store.GetReceiptSettings(ctx, tenantID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &Settings{TenantID: tenantID}, nil
+	}
+	return settings, err
+}
+
+// UpdateSettings saves a tenant's receipt settings
+func (s *Service) UpdateSettings(ctx context.Context, settings *Settings) (*Settings, error) {
+	ctx, span := s.tracer.Start(ctx, "UpdateSettings")
+	defer span.End()
+
+	if settings.DescriptorSuffix != "" {
+		if err := checkDescriptor("descriptor_suffix", settings.DescriptorSuffix, 1, 22); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSettings, err)
+		}
+	}
+	if settings.SupportEmail != "" {
+		if _, err := mail.ParseAddress(settings.SupportEmail); err != nil {
+			return nil, fmt.Errorf("%w: support_email is not an email address", ErrInvalidSettings)
+		}
+	}
+
+	return s.store.UpsertReceiptSettings(ctx, settings)
+}
@@ -0,0 +1,123 @@
+package reconciliation
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"time"
+
+	"apis/payments/services/stripe"
+)
+
+// EventMismatch is emitted for each mismatch a run finds
+const EventMismatch = "payments.reconciliation.mismatch"
+
+// Run statuses
+const (
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+// Mismatch kinds
+const (
+	// KindMissing is a provider transaction with no stored charge or refund
+	KindMissing = "missing"
+	// KindAmountDrift is a stored amount that differs from the provider's
+	KindAmountDrift = "amount_drift"
+	// KindOrphaned is a stored captured charge or succeeded refund the
+	// provider has no balance transaction for
+	KindOrphaned = "orphaned"
+)
+
+// Object types a mismatch can be about
+const (
+	ObjectCharge = "charge"
+	ObjectRefund = "refund"
+	ObjectPayout = "payout"
+)
+
+// ErrRunInProgress is returned when starting a run while another is in progress
+var ErrRunInProgress = errors.New("a reconciliation run is already in progress")
+
+// Run is one reconciliation of a period
+type Run struct {
+	ID                  string     `json:"id"`
+	Status              string     `json:"status"`
+	PeriodStart         time.Time  `json:"period_start"`
+	PeriodEnd           time.Time  `json:"period_end"`
+	TransactionsChecked int64      `json:"transactions_checked"`
+	PayoutsChecked      int64      `json:"payouts_checked"`
+	Matched             int64      `json:"matched"`
+	Mismatches          int64      `json:"mismatches"`
+	Error               string     `json:"error,omitempty"`
+	StartedAt           time.Time  `json:"started_at"`
+	CompletedAt         *time.Time `json:"completed_at,omitempty"`
+}
+
+// Mismatch is a difference between the provider and the stored records.
+// Amounts are in minor units; provider refund amounts are positive.
+type Mismatch struct {
+	ID                   string    `json:"id"`
+	RunID                string    `json:"run_id"`
+	Kind                 string    `json:"kind"`
+	ObjectType           string    `json:"object_type"`
+	ObjectID             string    `json:"object_id"`
+	BalanceTransactionID string    `json:"balance_transaction_id,omitempty"`
+	ProviderAmount       int64     `json:"provider_amount"`
+	LocalAmount          int64     `json:"local_amount"`
+	Currency             string    `json:"currency,omitempty"`
+	Detail               string    `json:"detail,omitempty"`
+	CreatedAt            time.Time `json:"created_at"`
+}
+
+// Report is a run with the mismatches it found
+type Report struct {
+	Run        *Run        `json:"run"`
+	Mismatches []*Mismatch `json:"mismatches"`
+}
+
+// Config controls the nightly reconciliation
+type Config struct {
+	Enabled bool
+	Hour    int // UTC hour at which the previous day is reconciled
+}
+
+// LoadConfig loads the reconciliation configuration from environment variables
+func LoadConfig() *Config {
+	config := &Config{
+		Enabled: true,
+		Hour:    2,
+	}
+
+	if enabled, err := strconv.ParseBool(os.Getenv("RECONCILIATION_ENABLED")); err == nil {
+		config.Enabled = enabled
+	}
+	if hour, err := strconv.Atoi(os.Getenv("RECONCILIATION_HOUR")); err == nil && hour >= 0 && hour < 24 {
+		config.Hour = hour
+	}
+
+	return config
+}
+
+// Store persists runs and their mismatches, and reads the stored charges and
+// refunds they are checked against
+type Store interface {
+	CreateReconciliationRun(ctx context.Context, run *Run) (*Run, error)
+	CompleteReconciliationRun(ctx context.Context, run *Run) (*Run, error)
+	GetReconciliationRun(ctx context.Context, runID string) (*Run, error)
+	ListReconciliationRuns(ctx context.Context, limit int) ([]*Run, error)
+	CreateReconciliationMismatch(ctx context.Context, mismatch *Mismatch) error
+	ListReconciliationMismatches(ctx context.Context, runID string) ([]*Mismatch, error)
+	GetCharge(ctx context.Context, chargeID string) (*stripe.Charge, error)
+	GetRefund(ctx context.Context, refundID string) (*stripe.Refund, error)
+	ListCapturedChargesCreatedBetween(ctx context.Context, from, to time.Time) ([]*stripe.Charge, error)
+	ListSucceededRefundsCreatedBetween(ctx context.Context, from, to time.Time) ([]*stripe.Refund, error)
+}
+
+// Provider lists the provider's balance transactions and payouts
+type Provider interface {
+	ListBalanceTransactions(ctx context.Context, filter stripe.BalanceTransactionFilter) ([]*stripe.BalanceTransaction, error)
+	ListPayouts(ctx context.Context, from, to time.Time) ([]*stripe.Payout, error)
+}
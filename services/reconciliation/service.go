@@ -0,0 +1,380 @@
+package reconciliation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"apis/payments/services/events"
+	"apis/payments/services/jobs"
+	"apis/payments/services/stripe"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 500
+)
+
+// Service reconciles the provider's balance transactions and payouts against
+// the stored charges and refunds
+type Service struct {
+	store    Store
+	provider Provider
+	emitter  *events.Emitter
+	config   *Config
+	tracer   trace.Tracer
+
+	running sync.Mutex
+}
+
+// NewService creates a new reconciliation service
+func NewService(store Store, provider Provider, emitter *events.Emitter, config *Config) *Service {
+	if config == nil {
+		config = LoadConfig()
+	}
+
+	return &Service{
+		store:    store,
+		provider: provider,
+		emitter:  emitter,
+		config:   config,
+		tracer:   otel.Tracer("payments.reconciliation"),
+	}
+}
+
+// Reconcile checks the period [from, to) and stores the run with its
+// mismatches. Only one run is in progress at a time.
+//
+// Every charge and refund balance transaction created in the period must
+// match a stored charge or refund of the same amount; amounts settled in
+// another currency are matched by ID only. Stored captured charges and
+// succeeded refunds created in the period must have a balance transaction,
+// which may fall after the period when a charge was captured later. Paid
+// payouts created in the period must equal the net of the transactions they
+// paid out.
+func (s *Service) Reconcile(ctx context.Context, from, to time.Time) (*Report, error) {
+	ctx, span := s.tracer.Start(ctx, "Reconcile")
+	defer span.End()
+
+	if !to.After(from) {
+		return nil, fmt.Errorf("period end must be after its start")
+	}
+	if !s.running.TryLock() {
+		return nil, ErrRunInProgress
+	}
+	defer s.running.Unlock()
+
+	run, err := s.store.CreateReconciliationRun(ctx, &Run{
+		ID:          fmt.Sprintf("recon_%s", uuid.New().String()),
+		Status:      StatusRunning,
+		PeriodStart: from.UTC(),
+		PeriodEnd:   to.UTC(),
+		StartedAt:   time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create reconciliation run: %w", err)
+	}
+
+	checker := &runChecker{service: s, run: run}
+	err = checker.check(ctx)
+
+	completedAt := time.Now().UTC()
+	run.CompletedAt = &completedAt
+	run.Status = StatusCompleted
+	if err != nil {
+		run.Status = StatusFailed
+		run.Error = err.Error()
+	}
+
+	completed, completeErr := s.store.CompleteReconciliationRun(ctx, run)
+	if completeErr != nil {
+		return nil, fmt.Errorf("failed to complete reconciliation run: %w", completeErr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reconciliation run %s failed: %w", run.ID, err)
+	}
+
+	return &Report{Run: completed, Mismatches: checker.mismatches}, nil
+}
+
+// Report returns a run with the mismatches it found
+func (s *Service) Report(ctx context.Context, runID string) (*Report, error) {
+	ctx, span := s.tracer.Start(ctx, "Report")
+	defer span.End()
+
+	run, err := s.store.GetReconciliationRun(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+
+	mismatches, err := s.store.ListReconciliationMismatches(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Report{Run: run, Mismatches: mismatches}, nil
+}
+
+// Runs returns the most recent runs, newest first
+func (s *Service) Runs(ctx context.Context, limit int) ([]*Run, error) {
+	ctx, span := s.tracer.Start(ctx, "Runs")
+	defer span.End()
+
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	return s.store.ListReconciliationRuns(ctx, min(limit, maxListLimit))
+}
+
+// PreviousDay returns the UTC day before the one containing now
+func PreviousDay(now time.Time) (from, to time.Time) {
+	to = now.UTC().Truncate(24 * time.Hour)
+	return to.Add(-24 * time.Hour), to
+}
+
+// Job reconciles the previous UTC day at the configured hour each night. It
+// returns nil unless reconciliation is enabled.
+func (s *Service) Job() *jobs.Job {
+	if !s.config.Enabled {
+		return nil
+	}
+
+	return &jobs.Job{
+		Name:     "reconciliation",
+		Schedule: fmt.Sprintf("0 %d * * *", s.config.Hour),
+		Run: func(ctx context.Context) error {
+			from, to := PreviousDay(time.Now())
+			report, err := s.Reconcile(ctx, from, to)
+			if err != nil {
+				return fmt.Errorf("reconciliation of %s failed: %w", from.Format(time.DateOnly), err)
+			}
+			if report.Run.Mismatches > 0 {
+				log.Printf("Reconciliation of %s found %d mismatches", from.Format(time.DateOnly), report.Run.Mismatches)
+			}
+			return nil
+		},
+	}
+}
+
+// runChecker checks one run's period, recording what it finds
+type runChecker struct {
+	service    *Service
+	run        *Run
+	seen       map[string]bool // Charge and refund IDs with a provider transaction
+	mismatches []*Mismatch
+}
+
+// check matches the period's transactions, then looks for orphaned records
+// and checks the period's payouts
+func (c *runChecker) check(ctx context.Context) error {
+	transactions, err := c.service.provider.ListBalanceTransactions(ctx, stripe.BalanceTransactionFilter{
+		CreatedFrom: c.run.PeriodStart,
+		CreatedTo:   c.run.PeriodEnd,
+	})
+	if err != nil {
+		return err
+	}
+
+	c.seen = make(map[string]bool)
+	for _, transaction := range transactions {
+		if err := c.matchTransaction(ctx, transaction); err != nil {
+			return err
+		}
+	}
+
+	if err := c.findOrphans(ctx); err != nil {
+		return err
+	}
+
+	return c.checkPayouts(ctx)
+}
+
+// matchTransaction matches a charge or refund transaction to the stored record
+func (c *runChecker) matchTransaction(ctx context.Context, transaction *stripe.BalanceTransaction) error {
+	var objectType string
+	var localAmount int64
+	var localCurrency string
+	var err error
+
+	switch transaction.Type {
+	case stripe.BalanceTransactionCharge, stripe.BalanceTransactionPayment:
+		objectType = ObjectCharge
+		var charge *stripe.Charge
+		if charge, err = c.service.store.GetCharge(ctx, transaction.SourceID); err == nil {
+			localAmount, localCurrency = charge.Amount, charge.Currency
+		}
+	case stripe.BalanceTransactionRefund, stripe.BalanceTransactionPaymentRefund:
+		objectType = ObjectRefund
+		var refund *stripe.Refund
+		if refund, err = c.service.store.GetRefund(ctx, transaction.SourceID); err == nil {
+			localAmount, localCurrency = refund.Amount, refund.Currency
+		}
+	default:
+		return nil
+	}
+
+	c.run.TransactionsChecked++
+	c.seen[transaction.SourceID] = true
+	providerAmount := abs(transaction.Amount)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.record(ctx, &Mismatch{
+			Kind:                 KindMissing,
+			ObjectType:           objectType,
+			ObjectID:             transaction.SourceID,
+			BalanceTransactionID: transaction.ID,
+			ProviderAmount:       providerAmount,
+			Currency:             transaction.Currency,
+			Detail:               fmt.Sprintf("no stored %s for balance transaction", objectType),
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	// Amounts settled in another currency can't be compared without the rate
+	if localCurrency == transaction.Currency && localAmount != providerAmount {
+		return c.record(ctx, &Mismatch{
+			Kind:                 KindAmountDrift,
+			ObjectType:           objectType,
+			ObjectID:             transaction.SourceID,
+			BalanceTransactionID: transaction.ID,
+			ProviderAmount:       providerAmount,
+			LocalAmount:          localAmount,
+			Currency:             transaction.Currency,
+			Detail:               fmt.Sprintf("stored amount differs from provider by %d", localAmount-providerAmount),
+		})
+	}
+
+	c.run.Matched++
+	return nil
+}
+
+// findOrphans flags stored captured charges and succeeded refunds created in
+// the period that have no provider transaction, looking up those not seen in
+// the period in case their transaction was created after it
+func (c *runChecker) findOrphans(ctx context.Context) error {
+	charges, err := c.service.store.ListCapturedChargesCreatedBetween(ctx, c.run.PeriodStart, c.run.PeriodEnd)
+	if err != nil {
+		return err
+	}
+	for _, charge := range charges {
+		if err := c.checkOrphan(ctx, ObjectCharge, charge.ID, charge.Amount, charge.Currency); err != nil {
+			return err
+		}
+	}
+
+	refunds, err := c.service.store.ListSucceededRefundsCreatedBetween(ctx, c.run.PeriodStart, c.run.PeriodEnd)
+	if err != nil {
+		return err
+	}
+	for _, refund := range refunds {
+		if err := c.checkOrphan(ctx, ObjectRefund, refund.ID, refund.Amount, refund.Currency); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkOrphan flags a stored record when the provider has no transaction for it
+func (c *runChecker) checkOrphan(ctx context.Context, objectType, objectID string, amount int64, currency string) error {
+	if c.seen[objectID] {
+		return nil
+	}
+
+	transactions, err := c.service.provider.ListBalanceTransactions(ctx, stripe.BalanceTransactionFilter{SourceID: objectID})
+	if err != nil {
+		return err
+	}
+	if len(transactions) > 0 {
+		return nil
+	}
+
+	return c.record(ctx, &Mismatch{
+		Kind:        KindOrphaned,
+		ObjectType:  objectType,
+		ObjectID:    objectID,
+		LocalAmount: amount,
+		Currency:    currency,
+		Detail:      fmt.Sprintf("no provider balance transaction for stored %s", objectType),
+	})
+}
+
+// checkPayouts checks that each paid payout equals the net of the
+// transactions it paid out
+func (c *runChecker) checkPayouts(ctx context.Context) error {
+	payouts, err := c.service.provider.ListPayouts(ctx, c.run.PeriodStart, c.run.PeriodEnd)
+	if err != nil {
+		return err
+	}
+
+	for _, payout := range payouts {
+		if payout.Status != stripe.PayoutPaid {
+			continue
+		}
+		c.run.PayoutsChecked++
+
+		transactions, err := c.service.provider.ListBalanceTransactions(ctx, stripe.BalanceTransactionFilter{PayoutID: payout.ID})
+		if err != nil {
+			return err
+		}
+
+		var net int64
+		for _, transaction := range transactions {
+			if transaction.Type != stripe.BalanceTransactionPayout {
+				net += transaction.Net
+			}
+		}
+
+		if net != payout.Amount {
+			if err := c.record(ctx, &Mismatch{
+				Kind:           KindAmountDrift,
+				ObjectType:     ObjectPayout,
+				ObjectID:       payout.ID,
+				ProviderAmount: payout.Amount,
+				LocalAmount:    net,
+				Currency:       payout.Currency,
+				Detail:         fmt.Sprintf("payout differs from the net of its %d transactions by %d", len(transactions), payout.Amount-net),
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// record stores a mismatch and emits it
+func (c *runChecker) record(ctx context.Context, mismatch *Mismatch) error {
+	mismatch.ID = fmt.Sprintf("recmm_%s", uuid.New().String())
+	mismatch.RunID = c.run.ID
+	mismatch.CreatedAt = time.Now().UTC()
+
+	if err := c.service.store.CreateReconciliationMismatch(ctx, mismatch); err != nil {
+		return fmt.Errorf("failed to store reconciliation mismatch: %w", err)
+	}
+	c.run.Mismatches++
+	c.mismatches = append(c.mismatches, mismatch)
+
+	if err := c.service.emitter.Emit(ctx, "reconciliation", EventMismatch, mismatch.ObjectID, mismatch); err != nil {
+		log.Printf("Failed to emit %s for %s %s: %v", EventMismatch, mismatch.ObjectType, mismatch.ObjectID, err)
+	}
+	return nil
+}
+
+// abs returns the absolute value of an amount
+func abs(amount int64) int64 {
+	if amount < 0 {
+		return -amount
+	}
+	return amount
+}
@@ -0,0 +1,89 @@
+package redis
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// ErrNil is returned for nil replies, such as GET of a missing key
+var ErrNil = goredis.Nil
+
+// Config locates a Redis server
+type Config struct {
+	Addr     string
+	Password string
+	DB       int
+	Timeout  time.Duration // Dial, read and write timeout of each command
+	PoolSize int           // Idle connections kept open
+}
+
+// LoadConfig loads the Redis server from REDIS_URL, e.g.
+// redis://:password@localhost:6379/0. It returns nil when REDIS_URL is unset.
+func LoadConfig() (*Config, error) {
+	raw := os.Getenv("REDIS_URL")
+	if raw == "" {
+		return nil, nil
+	}
+
+	config, err := ParseURL(raw)
+	if err != nil {
+		return nil, err
+	}
+	if ms, err := strconv.Atoi(os.Getenv("REDIS_TIMEOUT_MS")); err == nil && ms > 0 {
+		config.Timeout = time.Duration(ms) * time.Millisecond
+	}
+
+	return config, nil
+}
+
+// ParseURL parses a redis:// URL
+func ParseURL(raw string) (*Config, error) {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis URL: %w", err)
+	}
+	if parsed.Scheme != "redis" {
+		return nil, fmt.Errorf("invalid redis URL: unsupported scheme %q", parsed.Scheme)
+	}
+
+	config := &Config{Addr: parsed.Host, Timeout: time.Second, PoolSize: 8}
+	if parsed.Port() == "" {
+		config.Addr = net.JoinHostPort(parsed.Hostname(), "6379")
+	}
+	if password, ok := parsed.User.Password(); ok {
+		config.Password = password
+	}
+	if db := strings.TrimPrefix(parsed.Path, "/"); db != "" {
+		if config.DB, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("invalid redis URL: database %q is not a number", db)
+		}
+	}
+
+	return config, nil
+}
+
+// Client is a pooled go-redis client
+type Client = goredis.Client
+
+// NewClient creates a client. Connections are opened on first use, speak
+// RESP2 and are not tagged with the client library.
+func NewClient(config *Config) *Client {
+	return goredis.NewClient(&goredis.Options{
+		Addr:            config.Addr,
+		Password:        config.Password,
+		DB:              config.DB,
+		DialTimeout:     config.Timeout,
+		ReadTimeout:     config.Timeout,
+		WriteTimeout:    config.Timeout,
+		MaxIdleConns:    config.PoolSize,
+		Protocol:        2,
+		DisableIdentity: true,
+	})
+}
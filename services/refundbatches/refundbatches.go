@@ -0,0 +1,128 @@
+package refundbatches
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"apis/payments/services/config"
+	"apis/payments/services/refundguard"
+	"apis/payments/services/stripe"
+)
+
+// Batch statuses
+const (
+	StatusPending   = "pending"   // Waiting for its scheduled time or a worker
+	StatusRunning   = "running"   // A worker is issuing its refunds
+	StatusCompleted = "completed" // Every item has a result
+	StatusCanceled  = "canceled"  // Canceled before a worker started it
+)
+
+// Item statuses. Items without a result yet are pending.
+const (
+	ItemPending         = "pending"
+	ItemSucceeded       = "succeeded"
+	ItemPendingApproval = "pending_approval" // Held by the refund guard
+	ItemFailed          = "failed"
+)
+
+var (
+	// ErrBatchNotFound is returned for batches that don't exist or belong to another tenant
+	ErrBatchNotFound = errors.New("refund batch not found")
+	// ErrEmptyBatch is returned for batches without refunds
+	ErrEmptyBatch = errors.New("refund batch has no refunds")
+	// ErrBatchTooLarge is returned for batches with more refunds than allowed
+	ErrBatchTooLarge = errors.New("refund batch has too many refunds")
+	// ErrNotCancelable is returned when canceling a batch a worker has started
+	ErrNotCancelable = errors.New("refund batch has already started")
+)
+
+// CreateRequest asks for refunds to be issued in the background, no
+// earlier than ScheduledAt when it is set
+type CreateRequest struct {
+	Refunds     []stripe.RefundRequest `json:"refunds"`
+	ScheduledAt *time.Time             `json:"scheduled_at,omitempty"`
+}
+
+// Batch is a set of refunds issued in the background
+type Batch struct {
+	ID          string                 `json:"id"`
+	TenantID    string                 `json:"tenant_id"`
+	APIKeyID    string                 `json:"-"`
+	OperatorID  string                 `json:"operator_id,omitempty"`
+	Status      string                 `json:"status"`
+	Requests    []stripe.RefundRequest `json:"-"`
+	Items       []*Item                `json:"items"`
+	Progress    Progress               `json:"progress"`
+	ScheduledAt time.Time              `json:"scheduled_at"`
+	StartedAt   *time.Time             `json:"started_at,omitempty"`
+	CompletedAt *time.Time             `json:"completed_at,omitempty"`
+	CreatedAt   time.Time              `json:"created_at"`
+	UpdatedAt   time.Time              `json:"updated_at"`
+}
+
+// Actor returns who the batch's refunds are issued for
+func (b *Batch) Actor() refundguard.Actor {
+	return refundguard.Actor{TenantID: b.TenantID, APIKeyID: b.APIKeyID, OperatorID: b.OperatorID}
+}
+
+// Item is the result of one refund of a batch
+type Item struct {
+	Index      int    `json:"index"`
+	ChargeID   string `json:"charge_id"`
+	Status     string `json:"status"`
+	Amount     int64  `json:"amount,omitempty"`
+	RefundID   string `json:"refund_id,omitempty"`
+	ApprovalID string `json:"approval_id,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Progress counts a batch's items by status
+type Progress struct {
+	Total           int `json:"total"`
+	Processed       int `json:"processed"`
+	Succeeded       int `json:"succeeded"`
+	PendingApproval int `json:"pending_approval"`
+	Failed          int `json:"failed"`
+}
+
+// Config controls batch size and the workers issuing batches
+type Config struct {
+	MaxSize     int           // Most refunds one batch may hold
+	Concurrency int           // Refunds of a batch issued at once
+	Interval    time.Duration // How often workers look for due batches
+	BatchSize   int           // Batches claimed per run
+}
+
+// LoadConfig loads the refund batch configuration from environment variables
+func LoadConfig() *Config {
+	return &Config{
+		MaxSize:     config.Int("REFUND_BATCH_MAX_SIZE", 100),
+		Concurrency: config.Int("REFUND_BATCH_CONCURRENCY", 4),
+		Interval:    time.Duration(config.Int("REFUND_BATCH_INTERVAL_SECONDS", 30)) * time.Second,
+		BatchSize:   config.Int("REFUND_BATCH_RUN_SIZE", 10),
+	}
+}
+
+// Store persists batches and their item results. Getters return
+// sql.ErrNoRows when no batch matches, and claiming or finishing a batch in
+// another status does too.
+type Store interface {
+	CreateRefundBatch(ctx context.Context, batch *Batch) (*Batch, error)
+	GetRefundBatch(ctx context.Context, id string) (*Batch, error)
+	ListRunnableRefundBatches(ctx context.Context, now time.Time, limit int) ([]*Batch, error)
+	ClaimRefundBatch(ctx context.Context, id string) (*Batch, error)
+	FinishRefundBatch(ctx context.Context, id, from, status string) (*Batch, error)
+	RecordRefundBatchItem(ctx context.Context, batchID string, item *Item) error
+	ListRefundBatchItems(ctx context.Context, batchID string) ([]*Item, error)
+}
+
+// Refunder issues refunds through the refund guard
+type Refunder interface {
+	CreateRefund(ctx context.Context, actor refundguard.Actor, request *stripe.RefundRequest) (*stripe.Refund, *refundguard.Approval, error)
+}
+
+// ChargeStates rejects refunds of charges that can't be refunded
+type ChargeStates interface {
+	Allows(ctx context.Context, chargeID string, to ...string) error
+}
@@ -0,0 +1,293 @@
+package refundbatches
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"apis/payments/services/chargestate"
+	"apis/payments/services/refundguard"
+	"apis/payments/services/tenancy"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Service issues batches of refunds, and refunds scheduled for later, in
+// the background
+type Service struct {
+	store        Store
+	refunds      Refunder
+	chargeStates ChargeStates
+	config       *Config
+	tracer       trace.Tracer
+}
+
+// NewService creates a new refund batch service
+func NewService(store Store, refunds Refunder, chargeStates ChargeStates, config *Config) *Service {
+	if config == nil {
+		config = LoadConfig()
+	}
+
+	return &Service{
+		store:        store,
+		refunds:      refunds,
+		chargeStates: chargeStates,
+		config:       config,
+		tracer:       otel.Tracer("payments.refundbatches"),
+	}
+}
+
+// Create queues refunds to be issued for an actor, at once or at the
+// scheduled time
+func (s *Service) Create(ctx context.Context, actor refundguard.Actor, req CreateRequest) (*Batch, error) {
+	ctx, span := s.tracer.Start(ctx, "Create")
+	defer span.End()
+
+	if len(req.Refunds) == 0 {
+		return nil, ErrEmptyBatch
+	}
+	if len(req.Refunds) > s.config.MaxSize {
+		return nil, fmt.Errorf("%w: %d is more than %d", ErrBatchTooLarge, len(req.Refunds), s.config.MaxSize)
+	}
+
+	scheduledAt := time.Now().UTC()
+	if req.ScheduledAt != nil && req.ScheduledAt.After(scheduledAt) {
+		scheduledAt = req.ScheduledAt.UTC()
+	}
+
+	batch, err := s.store.CreateRefundBatch(ctx, &Batch{
+		ID:          fmt.Sprintf("rfb_%s", uuid.New().String()),
+		TenantID:    actor.TenantID,
+		APIKeyID:    actor.APIKeyID,
+		OperatorID:  actor.OperatorID,
+		Status:      StatusPending,
+		Requests:    req.Refunds,
+		ScheduledAt: scheduledAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create refund batch: %w", err)
+	}
+
+	return s.withItems(batch, nil), nil
+}
+
+// Get retrieves a tenant's batch with the result of each of its refunds
+func (s *Service) Get(ctx context.Context, tenantID, id string) (*Batch, error) {
+	ctx, span := s.tracer.Start(ctx, "Get")
+	defer span.End()
+
+	batch, err := s.store.GetRefundBatch(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrBatchNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if batch.TenantID != tenantID {
+		return nil, ErrBatchNotFound
+	}
+
+	results, err := s.store.ListRefundBatchItems(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list refund batch items: %w", err)
+	}
+
+	return s.withItems(batch, results), nil
+}
+
+// Cancel stops a tenant's batch that no worker has started, such as a
+// scheduled refund
+func (s *Service) Cancel(ctx context.Context, tenantID, id string) (*Batch, error) {
+	ctx, span := s.tracer.Start(ctx, "Cancel")
+	defer span.End()
+
+	if _, err := s.Get(ctx, tenantID, id); err != nil {
+		return nil, err
+	}
+
+	canceled, err := s.store.FinishRefundBatch(ctx, id, StatusPending, StatusCanceled)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotCancelable
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel refund batch: %w", err)
+	}
+
+	return s.withItems(canceled, nil), nil
+}
+
+// RunDue issues the refunds of batches due by now, and of batches whose
+// worker stopped mid-run, returning how many batches completed
+func (s *Service) RunDue(ctx context.Context, now time.Time) (int, error) {
+	ctx, span := s.tracer.Start(ctx, "RunDue")
+	defer span.End()
+
+	batches, err := s.store.ListRunnableRefundBatches(ctx, now, s.config.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list runnable refund batches: %w", err)
+	}
+
+	completed := 0
+	var errs []error
+	for _, batch := range batches {
+		claimed, err := s.store.ClaimRefundBatch(ctx, batch.ID)
+		if err != nil {
+			// Claimed by another worker or canceled
+			continue
+		}
+
+		if err := s.run(ctx, claimed); err != nil {
+			errs = append(errs, fmt.Errorf("refund batch %s: %w", claimed.ID, err))
+			continue
+		}
+		completed++
+	}
+
+	return completed, errors.Join(errs...)
+}
+
+// Start issues due batches every configured interval until the returned
+// stop function is called
+func (s *Service) Start() (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(s.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				completed, err := s.RunDue(context.Background(), time.Now())
+				if err != nil {
+					log.Printf("Refund batch run failed: %v", err)
+				}
+				if completed > 0 {
+					log.Printf("Completed %d refund batches", completed)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// run issues a claimed batch's refunds that have no result yet with a pool
+// of workers, recording each result as it comes in. Refunds act for the
+// batch's tenant, since workers act for none.
+func (s *Service) run(ctx context.Context, batch *Batch) error {
+	ctx = tenancy.WithTenant(ctx, batch.TenantID)
+
+	results, err := s.store.ListRefundBatchItems(ctx, batch.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list refund batch items: %w", err)
+	}
+	done := make(map[int]bool, len(results))
+	for _, result := range results {
+		done[result.Index] = true
+	}
+
+	indexes := make(chan int)
+	errs := make([]error, len(batch.Requests))
+	var wg sync.WaitGroup
+	for w := 0; w < max(1, s.config.Concurrency); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				item := s.refund(ctx, batch, index)
+				if err := s.store.RecordRefundBatchItem(ctx, batch.ID, item); err != nil {
+					errs[index] = fmt.Errorf("failed to record item %d: %w", index, err)
+				}
+			}
+		}()
+	}
+	for index := range batch.Requests {
+		if !done[index] {
+			indexes <- index
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	// Batches with unrecorded results stay running and are picked up again
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	if _, err := s.store.FinishRefundBatch(ctx, batch.ID, StatusRunning, StatusCompleted); err != nil {
+		return fmt.Errorf("failed to complete refund batch: %w", err)
+	}
+	return nil
+}
+
+// refund issues one refund of a batch the way the API does. The idempotency
+// key makes a rerun after a crash return the refund already issued.
+func (s *Service) refund(ctx context.Context, batch *Batch, index int) *Item {
+	request := batch.Requests[index]
+	item := &Item{Index: index, ChargeID: request.ChargeID}
+
+	// Only captured charges can be refunded
+	if err := s.chargeStates.Allows(ctx, request.ChargeID, chargestate.StatePartiallyRefunded, chargestate.StateRefunded); err != nil {
+		item.Status, item.Error = ItemFailed, err.Error()
+		return item
+	}
+
+	request.IdempotencyKey = fmt.Sprintf("%s-%d", batch.ID, index)
+	refund, approval, err := s.refunds.CreateRefund(ctx, batch.Actor(), &request)
+	switch {
+	case err != nil:
+		item.Status, item.Error = ItemFailed, err.Error()
+	case approval != nil:
+		item.Status, item.Amount, item.ApprovalID = ItemPendingApproval, approval.Amount, approval.ID
+	default:
+		item.Status, item.Amount, item.RefundID = ItemSucceeded, refund.Amount, refund.ID
+	}
+
+	return item
+}
+
+// withItems lists every refund of a batch with its result, or as pending
+// when it has none yet, and counts them
+func (s *Service) withItems(batch *Batch, results []*Item) *Batch {
+	recorded := make(map[int]*Item, len(results))
+	for _, result := range results {
+		recorded[result.Index] = result
+	}
+
+	batch.Items = make([]*Item, len(batch.Requests))
+	batch.Progress = Progress{Total: len(batch.Requests)}
+	for index, request := range batch.Requests {
+		item, ok := recorded[index]
+		if !ok {
+			item = &Item{Index: index, ChargeID: request.ChargeID, Status: ItemPending, Amount: request.Amount}
+		}
+		item.ChargeID = request.ChargeID
+		batch.Items[index] = item
+
+		switch item.Status {
+		case ItemSucceeded:
+			batch.Progress.Succeeded++
+		case ItemPendingApproval:
+			batch.Progress.PendingApproval++
+		case ItemFailed:
+			batch.Progress.Failed++
+		}
+	}
+	batch.Progress.Processed = batch.Progress.Succeeded + batch.Progress.PendingApproval + batch.Progress.Failed
+
+	return batch
+}
@@ -0,0 +1,32 @@
+package refundguard
+
+import (
+	"fmt"
+	"time"
+
+	"apis/payments/services/alerts"
+)
+
+// Alert is raised when refund volume exceeds its limits
+type Alert struct {
+	TenantID   string      `json:"tenant_id"`
+	APIKeyID   string      `json:"api_key_id"`
+	OperatorID string      `json:"operator_id"`
+	ApprovalID string      `json:"approval_id"`
+	Amount     int64       `json:"amount"`
+	Violations []Violation `json:"violations"`
+	RaisedAt   time.Time   `json:"raised_at"`
+}
+
+// Alerter delivers refund anomaly alerts
+type Alerter = alerts.Alerter[*Alert]
+
+// Messages describes each violation for the service log
+func (a *Alert) Messages() []string {
+	messages := make([]string, 0, len(a.Violations))
+	for _, v := range a.Violations {
+		messages = append(messages, fmt.Sprintf("refund volume anomaly on %s %s (count %d/%d, amount %d/%d, hard=%t); refund held as %s",
+			v.Dimension, v.Key, v.Usage.Count, v.Threshold.Count, v.Usage.Amount, v.Threshold.Amount, v.Hard, a.ApprovalID))
+	}
+	return messages
+}
@@ -0,0 +1,226 @@
+package refundguard
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"apis/payments/services/config"
+	"apis/payments/services/stripe"
+)
+
+// Dimensions refund volume is tracked along
+const (
+	DimensionTenant   = "tenant"
+	DimensionAPIKey   = "api_key"
+	DimensionOperator = "operator"
+	DimensionRefund   = "refund" // A single refund over the approval threshold
+)
+
+// Approval statuses
+const (
+	ApprovalPending   = "pending"
+	ApprovalApproving = "approving" // Claimed by an approver while its refund is issued
+	ApprovalApproved  = "approved"
+	ApprovalRejected  = "rejected"
+)
+
+// EventApprovalRequested is emitted when a refund is held for approval
+const EventApprovalRequested = "payments.refund.approval_requested"
+
+var (
+	// ErrSelfApproval is returned when an operator tries to approve their own refund
+	ErrSelfApproval = errors.New("refunds held for step-up approval must be approved by a different operator")
+	// ErrApproverRole is returned when an operator without an approver role approves a refund over the threshold
+	ErrApproverRole = errors.New("refunds over the approval threshold must be approved by an operator with an approver role")
+	// ErrApprovalNotFound is returned for approvals that do not exist or belong to another tenant
+	ErrApprovalNotFound = errors.New("refund approval not found")
+	// ErrRefundPolicy is returned for refunds the refund policy does not allow
+	ErrRefundPolicy = errors.New("refund not allowed by policy")
+	// ErrApprovalDecided is returned when deciding an approval that is no longer pending
+	ErrApprovalDecided = errors.New("refund approval has already been decided")
+)
+
+// Actor identifies who is issuing or approving a refund
+type Actor struct {
+	TenantID   string   `json:"tenant_id"`
+	APIKeyID   string   `json:"api_key_id"`
+	OperatorID string   `json:"operator_id"`
+	Roles      []string `json:"roles,omitempty"`
+}
+
+// Limits configures refund velocity detection. Volume within the current
+// window is compared to the average window over the baseline period; a
+// dimension is anomalous when it exceeds the baseline times Multiplier.
+// Floors keep low-volume tenants from tripping on their first few refunds,
+// and hard limits apply regardless of history.
+type Limits struct {
+	Window         time.Duration `json:"window"`
+	BaselinePeriod time.Duration `json:"baseline_period"`
+	Multiplier     float64       `json:"multiplier"`
+	MinCount       int64         `json:"min_count"`
+	MinAmount      int64         `json:"min_amount"`
+	HardCount      int64         `json:"hard_count"`  // 0 disables the hard limit
+	HardAmount     int64         `json:"hard_amount"` // 0 disables the hard limit
+}
+
+// DefaultLimits returns the limits used when none are configured
+func DefaultLimits() *Limits {
+	return &Limits{
+		Window:         time.Hour,
+		BaselinePeriod: 7 * 24 * time.Hour,
+		Multiplier:     3,
+		MinCount:       10,
+		MinAmount:      100000,
+		HardCount:      100,
+		HardAmount:     1000000,
+	}
+}
+
+// LoadLimits loads refund velocity limits from environment variables
+func LoadLimits() *Limits {
+	defaults := DefaultLimits()
+	return &Limits{
+		Window:         time.Duration(config.Int("REFUND_VELOCITY_WINDOW_MINUTES", int(defaults.Window/time.Minute))) * time.Minute,
+		BaselinePeriod: time.Duration(config.Int("REFUND_VELOCITY_BASELINE_HOURS", int(defaults.BaselinePeriod/time.Hour))) * time.Hour,
+		Multiplier:     config.Float("REFUND_VELOCITY_MULTIPLIER", defaults.Multiplier),
+		MinCount:       int64(config.Int("REFUND_VELOCITY_MIN_COUNT", int(defaults.MinCount))),
+		MinAmount:      int64(config.Int("REFUND_VELOCITY_MIN_AMOUNT", int(defaults.MinAmount))),
+		HardCount:      int64(config.Int("REFUND_VELOCITY_HARD_COUNT", int(defaults.HardCount))),
+		HardAmount:     int64(config.Int("REFUND_VELOCITY_HARD_AMOUNT", int(defaults.HardAmount))),
+	}
+}
+
+// Policy limits what a single refund may do. Refunds over MaxPercent or
+// outside Window are rejected; refunds over ApprovalThreshold are held for
+// an operator with one of ApproverRoles to approve.
+type Policy struct {
+	MaxPercent        float64       `json:"max_percent"`        // Share of a charge that may be refunded in total; 0 disables
+	Window            time.Duration `json:"window"`             // How long after the charge refunds are allowed; 0 disables
+	ApprovalThreshold int64         `json:"approval_threshold"` // 0 disables
+	ApproverRoles     []string      `json:"approver_roles"`     // Empty lets any other operator approve
+}
+
+// LoadPolicy loads the refund policy from environment variables
+func LoadPolicy() *Policy {
+	policy := &Policy{
+		MaxPercent:        config.Float("REFUND_POLICY_MAX_PERCENT", 0),
+		Window:            time.Duration(config.Int("REFUND_POLICY_WINDOW_DAYS", 0)) * 24 * time.Hour,
+		ApprovalThreshold: int64(config.Int("REFUND_APPROVAL_THRESHOLD", 0)),
+	}
+
+	for _, role := range strings.Split(os.Getenv("REFUND_APPROVER_ROLES"), ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			policy.ApproverRoles = append(policy.ApproverRoles, role)
+		}
+	}
+
+	return policy
+}
+
+// CanApprove reports whether an operator holding the given roles may
+// approve refunds over the approval threshold
+func (p *Policy) CanApprove(roles []string) bool {
+	if len(p.ApproverRoles) == 0 {
+		return true
+	}
+	for _, allowed := range p.ApproverRoles {
+		for _, role := range roles {
+			if allowed == role {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Threshold returns the count and amount allowed in one window given the
+// usage observed over the baseline period
+func (l *Limits) Threshold(baseline Usage) Usage {
+	windows := float64(l.BaselinePeriod) / float64(l.Window)
+	if windows < 1 {
+		windows = 1
+	}
+
+	threshold := Usage{
+		Count:  int64(float64(baseline.Count) / windows * l.Multiplier),
+		Amount: int64(float64(baseline.Amount) / windows * l.Multiplier),
+	}
+	if threshold.Count < l.MinCount {
+		threshold.Count = l.MinCount
+	}
+	if threshold.Amount < l.MinAmount {
+		threshold.Amount = l.MinAmount
+	}
+
+	return threshold
+}
+
+// Usage is the refund volume seen for one dimension
+type Usage struct {
+	Count  int64 `json:"count"`
+	Amount int64 `json:"amount"`
+}
+
+// Violation describes a dimension whose refund volume exceeded its limits
+type Violation struct {
+	Dimension string `json:"dimension"`
+	Key       string `json:"key"`
+	Hard      bool   `json:"hard"` // Exceeded an absolute limit rather than the baseline
+	Usage     Usage  `json:"usage"`
+	Threshold Usage  `json:"threshold"`
+}
+
+// Activity records a refund issued by an actor
+type Activity struct {
+	ID         string    `json:"id"`
+	TenantID   string    `json:"tenant_id"`
+	APIKeyID   string    `json:"api_key_id"`
+	OperatorID string    `json:"operator_id"`
+	RefundID   string    `json:"refund_id"`
+	ChargeID   string    `json:"charge_id"`
+	Amount     int64     `json:"amount"`
+	Currency   string    `json:"currency"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Approval is a refund held for step-up approval
+type Approval struct {
+	ID         string               `json:"id"`
+	TenantID   string               `json:"tenant_id"`
+	APIKeyID   string               `json:"api_key_id"`
+	OperatorID string               `json:"operator_id"`
+	Request    stripe.RefundRequest `json:"request"`
+	Amount     int64                `json:"amount"`
+	Violations []Violation          `json:"violations"`
+	Status     string               `json:"status"`
+	DecidedBy  string               `json:"decided_by,omitempty"`
+	RefundID   string               `json:"refund_id,omitempty"`
+	CreatedAt  time.Time            `json:"created_at"`
+	UpdatedAt  time.Time            `json:"updated_at"`
+	DecidedAt  *time.Time           `json:"decided_at,omitempty"`
+}
+
+// Store persists refund activity and approvals
+type Store interface {
+	RecordRefundActivity(ctx context.Context, activity *Activity) error
+	GetRefundUsage(ctx context.Context, dimension, key string, since time.Time) (Usage, error)
+	CreateRefundApproval(ctx context.Context, approval *Approval) (*Approval, error)
+	GetRefundApproval(ctx context.Context, id string) (*Approval, error)
+	ListPendingRefundApprovals(ctx context.Context, tenantID string) ([]*Approval, error)
+	ClaimRefundApproval(ctx context.Context, id, decidedBy string) (bool, error)
+	ReleaseRefundApproval(ctx context.Context, id string) error
+	DecideRefundApproval(ctx context.Context, id, status, decidedBy, refundID string) (*Approval, error)
+}
+
+// Refunder issues refunds at the provider
+type Refunder interface {
+	CreateRefund(ctx context.Context, request *stripe.RefundRequest) (*stripe.Refund, error)
+}
+
+// ChargeLookup resolves the amount of a full refund
+type ChargeLookup interface {
+	GetCharge(ctx context.Context, chargeID string) (*stripe.Charge, error)
+}
@@ -0,0 +1,474 @@
+package refundguard
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"apis/payments/services/alerts"
+	"apis/payments/services/events"
+	"apis/payments/services/money"
+	"apis/payments/services/stripe"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Service enforces refund velocity limits and the refund policy, holding
+// anomalous and large refunds for step-up approval
+type Service struct {
+	store    Store
+	refunder Refunder
+	charges  ChargeLookup
+	alerter  Alerter
+	limits   *Limits
+	policy   *Policy
+	emitter  *events.Emitter
+	tracer   trace.Tracer
+}
+
+// NewService creates a new refund guard service
+func NewService(store Store, refunder Refunder, charges ChargeLookup, alerter Alerter, limits *Limits) *Service {
+	if limits == nil {
+		limits = DefaultLimits()
+	}
+	if alerter == nil {
+		alerter = alerts.LogAlerter[*Alert]{}
+	}
+
+	return &Service{
+		store:    store,
+		refunder: refunder,
+		charges:  charges,
+		alerter:  alerter,
+		limits:   limits,
+		policy:   &Policy{},
+		tracer:   otel.Tracer("payments.refundguard"),
+	}
+}
+
+// UsePolicy applies a refund policy to every refund
+func (s *Service) UsePolicy(policy *Policy) {
+	s.policy = policy
+}
+
+// UseEmitter emits an event whenever a refund is held for approval
+func (s *Service) UseEmitter(emitter *events.Emitter) {
+	s.emitter = emitter
+}
+
+// CreateRefund issues a refund unless it pushes the actor's refund volume past
+// its limits or is over the policy's approval threshold, in which case the
+// refund is held for approval. Refunds the policy does not allow return
+// ErrRefundPolicy. Exactly one of the returned refund and approval is non-nil
+// on success.
+func (s *Service) CreateRefund(ctx context.Context, actor Actor, request *stripe.RefundRequest) (*stripe.Refund, *Approval, error) {
+	ctx, span := s.tracer.Start(ctx, "CreateRefund")
+	defer span.End()
+
+	amount, violations, err := s.evaluateRefund(ctx, actor, request)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(violations) > 0 {
+		approval, err := s.hold(ctx, actor, request, amount, violations)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, approval, nil
+	}
+
+	refund, err := s.issue(ctx, actor, request)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return refund, nil, nil
+}
+
+// Preview returns the amount a refund would be for and the limits it would
+// exceed, without issuing or holding it
+func (s *Service) Preview(ctx context.Context, actor Actor, request *stripe.RefundRequest) (int64, []Violation, error) {
+	ctx, span := s.tracer.Start(ctx, "Preview")
+	defer span.End()
+
+	return s.evaluateRefund(ctx, actor, request)
+}
+
+// Evaluate returns the limits a refund of the given amount would exceed
+func (s *Service) Evaluate(ctx context.Context, actor Actor, amount int64) ([]Violation, error) {
+	ctx, span := s.tracer.Start(ctx, "Evaluate")
+	defer span.End()
+
+	now := time.Now()
+	dimensions := []struct{ name, key string }{
+		{DimensionTenant, actor.TenantID},
+		{DimensionAPIKey, actor.APIKeyID},
+		{DimensionOperator, actor.OperatorID},
+	}
+
+	var violations []Violation
+	for _, d := range dimensions {
+		if d.key == "" {
+			continue
+		}
+
+		current, err := s.store.GetRefundUsage(ctx, d.name, d.key, now.Add(-s.limits.Window))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s refund usage: %w", d.name, err)
+		}
+
+		baseline, err := s.store.GetRefundUsage(ctx, d.name, d.key, now.Add(-s.limits.BaselinePeriod))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s refund baseline: %w", d.name, err)
+		}
+
+		projected := Usage{Count: current.Count + 1, Amount: current.Amount + amount}
+
+		if (s.limits.HardCount > 0 && projected.Count > s.limits.HardCount) ||
+			(s.limits.HardAmount > 0 && projected.Amount > s.limits.HardAmount) {
+			violations = append(violations, Violation{
+				Dimension: d.name,
+				Key:       d.key,
+				Hard:      true,
+				Usage:     projected,
+				Threshold: Usage{Count: s.limits.HardCount, Amount: s.limits.HardAmount},
+			})
+			continue
+		}
+
+		threshold := s.limits.Threshold(baseline)
+		if projected.Count > threshold.Count || projected.Amount > threshold.Amount {
+			violations = append(violations, Violation{
+				Dimension: d.name,
+				Key:       d.key,
+				Usage:     projected,
+				Threshold: threshold,
+			})
+		}
+	}
+
+	return violations, nil
+}
+
+// ApproveRefund issues a held refund. The approver must belong to the
+// refund's tenant and be a different operator and API key from the ones
+// that requested it, and refunds over the approval threshold need an
+// approver with one of the policy's approver roles. The approval is claimed
+// before the refund is issued, so concurrent approvals and rejections cannot
+// issue it twice or issue a rejected refund.
+func (s *Service) ApproveRefund(ctx context.Context, approvalID string, approver Actor) (*Approval, error) {
+	ctx, span := s.tracer.Start(ctx, "ApproveRefund")
+	defer span.End()
+
+	approval, err := s.pendingApproval(ctx, approvalID, approver)
+	if err != nil {
+		return nil, err
+	}
+	if overThreshold(approval.Violations) && !s.policy.CanApprove(approver.Roles) {
+		return nil, ErrApproverRole
+	}
+
+	claimed, err := s.store.ClaimRefundApproval(ctx, approval.ID, approver.OperatorID)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, fmt.Errorf("%w: %s", ErrApprovalDecided, approvalID)
+	}
+
+	// The approval ID keys the refund, so a provider retry cannot issue it twice
+	approval.Request.IdempotencyKey = approval.ID
+	actor := Actor{TenantID: approval.TenantID, APIKeyID: approval.APIKeyID, OperatorID: approval.OperatorID}
+	refund, err := s.issue(ctx, actor, &approval.Request)
+	if err != nil {
+		if releaseErr := s.store.ReleaseRefundApproval(ctx, approval.ID); releaseErr != nil {
+			log.Printf("Failed to release refund approval %s after a failed refund: %v", approval.ID, releaseErr)
+		}
+		return nil, err
+	}
+
+	approved, err := s.store.DecideRefundApproval(ctx, approval.ID, ApprovalApproved, approver.OperatorID, refund.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record approval: %w", err)
+	}
+
+	log.Printf("Refund approval %s approved by %s, issued refund %s", approval.ID, approver.OperatorID, refund.ID)
+	return approved, nil
+}
+
+// RejectRefund rejects a held refund without issuing it
+func (s *Service) RejectRefund(ctx context.Context, approvalID string, approver Actor) (*Approval, error) {
+	ctx, span := s.tracer.Start(ctx, "RejectRefund")
+	defer span.End()
+
+	approval, err := s.pendingApproval(ctx, approvalID, approver)
+	if err != nil {
+		return nil, err
+	}
+
+	rejected, err := s.store.DecideRefundApproval(ctx, approval.ID, ApprovalRejected, approver.OperatorID, "")
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrApprovalDecided, approvalID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to record rejection: %w", err)
+	}
+
+	log.Printf("Refund approval %s rejected by %s", approval.ID, approver.OperatorID)
+	return rejected, nil
+}
+
+// GetApproval retrieves one of a tenant's refund approvals
+func (s *Service) GetApproval(ctx context.Context, tenantID, approvalID string) (*Approval, error) {
+	ctx, span := s.tracer.Start(ctx, "GetApproval")
+	defer span.End()
+
+	if approvalID == "" {
+		return nil, fmt.Errorf("approval ID cannot be empty")
+	}
+
+	return s.tenantApproval(ctx, tenantID, approvalID)
+}
+
+// ListPendingApprovals lists the refunds awaiting approval for a tenant
+func (s *Service) ListPendingApprovals(ctx context.Context, tenantID string) ([]*Approval, error) {
+	ctx, span := s.tracer.Start(ctx, "ListPendingApprovals")
+	defer span.End()
+
+	if tenantID == "" {
+		return nil, fmt.Errorf("tenant ID cannot be empty")
+	}
+
+	return s.store.ListPendingRefundApprovals(ctx, tenantID)
+}
+
+// evaluateRefund resolves a refund's amount and returns the velocity limits
+// and approval threshold it exceeds, or ErrRefundPolicy when the policy
+// rejects it outright
+func (s *Service) evaluateRefund(ctx context.Context, actor Actor, request *stripe.RefundRequest) (int64, []Violation, error) {
+	amount, err := s.refundAmount(ctx, request)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if err := s.checkPolicy(ctx, request.ChargeID, amount); err != nil {
+		return 0, nil, err
+	}
+
+	violations, err := s.Evaluate(ctx, actor, amount)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if s.policy.ApprovalThreshold > 0 && amount > s.policy.ApprovalThreshold {
+		violations = append(violations, Violation{
+			Dimension: DimensionRefund,
+			Key:       request.ChargeID,
+			Hard:      true,
+			Usage:     Usage{Count: 1, Amount: amount},
+			Threshold: Usage{Count: 1, Amount: s.policy.ApprovalThreshold},
+		})
+	}
+
+	return amount, violations, nil
+}
+
+// checkPolicy rejects refunds that would take more than the policy's share
+// of a charge or that come after the refund window
+func (s *Service) checkPolicy(ctx context.Context, chargeID string, amount int64) error {
+	if s.policy.MaxPercent <= 0 && s.policy.Window <= 0 {
+		return nil
+	}
+
+	charge, err := s.charges.GetCharge(ctx, chargeID)
+	if err != nil {
+		return fmt.Errorf("failed to look up charge: %w", err)
+	}
+
+	if s.policy.MaxPercent > 0 {
+		allowed := int64(float64(charge.Amount) * s.policy.MaxPercent / 100)
+		if charge.AmountRefunded+amount > allowed {
+			return fmt.Errorf("%w: refunds of charge %s would exceed %g%% of it", ErrRefundPolicy, chargeID, s.policy.MaxPercent)
+		}
+	}
+
+	if s.policy.Window > 0 && time.Since(time.Unix(charge.Created, 0)) > s.policy.Window {
+		return fmt.Errorf("%w: charge %s is past the refund window", ErrRefundPolicy, chargeID)
+	}
+
+	return nil
+}
+
+// refundAmount resolves the amount a refund request will return. Decimal
+// amounts are normalised to minor units so the amount that was evaluated is
+// the amount that gets refunded.
+func (s *Service) refundAmount(ctx context.Context, request *stripe.RefundRequest) (int64, error) {
+	if request.Amount > 0 && request.AmountDecimal == "" {
+		return request.Amount, nil
+	}
+
+	charge, err := s.charges.GetCharge(ctx, request.ChargeID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up charge: %w", err)
+	}
+
+	if request.AmountDecimal != "" {
+		amount, err := money.ResolveAmount(request.Amount, request.AmountDecimal, charge.Currency)
+		if err != nil {
+			return 0, fmt.Errorf("validation failed: %w", err)
+		}
+		request.Amount, request.AmountDecimal = amount, ""
+		return amount, nil
+	}
+
+	return charge.Amount, nil
+}
+
+// hold records a refund for approval, announces it and raises an alert when
+// the refund volume is anomalous
+func (s *Service) hold(ctx context.Context, actor Actor, request *stripe.RefundRequest, amount int64, violations []Violation) (*Approval, error) {
+	approval, err := s.store.CreateRefundApproval(ctx, &Approval{
+		ID:         fmt.Sprintf("rfa_%s", uuid.New().String()),
+		TenantID:   actor.TenantID,
+		APIKeyID:   actor.APIKeyID,
+		OperatorID: actor.OperatorID,
+		Request:    *request,
+		Amount:     amount,
+		Violations: violations,
+		Status:     ApprovalPending,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create refund approval: %w", err)
+	}
+
+	s.emit(ctx, approval)
+
+	// Refunds over the approval threshold are expected, so only anomalous
+	// volume raises an alert
+	if !anomalous(violations) {
+		return approval, nil
+	}
+
+	alert := &Alert{
+		TenantID:   actor.TenantID,
+		APIKeyID:   actor.APIKeyID,
+		OperatorID: actor.OperatorID,
+		ApprovalID: approval.ID,
+		Amount:     amount,
+		Violations: violations,
+		RaisedAt:   time.Now().UTC(),
+	}
+	if err := s.alerter.Alert(ctx, alert); err != nil {
+		// The refund is already held, so a failed notification must not release it
+		log.Printf("Failed to deliver refund anomaly alert for approval %s: %v", approval.ID, err)
+	}
+
+	return approval, nil
+}
+
+// issue creates the refund at the provider and records it against the actor
+func (s *Service) issue(ctx context.Context, actor Actor, request *stripe.RefundRequest) (*stripe.Refund, error) {
+	refund, err := s.refunder.CreateRefund(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.store.RecordRefundActivity(ctx, &Activity{
+		ID:         fmt.Sprintf("rfact_%s", uuid.New().String()),
+		TenantID:   actor.TenantID,
+		APIKeyID:   actor.APIKeyID,
+		OperatorID: actor.OperatorID,
+		RefundID:   refund.ID,
+		ChargeID:   refund.ChargeID,
+		Amount:     refund.Amount,
+		Currency:   refund.Currency,
+	})
+	if err != nil {
+		// The refund went through; losing one activity row only weakens the baseline
+		log.Printf("Failed to record refund activity for %s: %v", refund.ID, err)
+	}
+
+	return refund, nil
+}
+
+// pendingApproval loads an approval that the approver is allowed to decide
+func (s *Service) pendingApproval(ctx context.Context, approvalID string, approver Actor) (*Approval, error) {
+	if approvalID == "" {
+		return nil, fmt.Errorf("approval ID cannot be empty")
+	}
+	if approver.OperatorID == "" {
+		return nil, fmt.Errorf("approver ID cannot be empty")
+	}
+
+	approval, err := s.tenantApproval(ctx, approver.TenantID, approvalID)
+	if err != nil {
+		return nil, err
+	}
+
+	if approval.Status != ApprovalPending {
+		return nil, fmt.Errorf("%w: %s is %s", ErrApprovalDecided, approvalID, approval.Status)
+	}
+
+	// A key that triggered a hold cannot release it, whoever it claims to be
+	if approval.OperatorID != "" && approval.OperatorID == approver.OperatorID {
+		return nil, ErrSelfApproval
+	}
+	if approval.APIKeyID != "" && approval.APIKeyID == approver.APIKeyID {
+		return nil, ErrSelfApproval
+	}
+
+	return approval, nil
+}
+
+// tenantApproval loads an approval, reporting approvals of other tenants as
+// not found
+func (s *Service) tenantApproval(ctx context.Context, tenantID, approvalID string) (*Approval, error) {
+	approval, err := s.store.GetRefundApproval(ctx, approvalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refund approval: %w", err)
+	}
+
+	if approval.TenantID != tenantID {
+		return nil, ErrApprovalNotFound
+	}
+
+	return approval, nil
+}
+
+// emit publishes the approval request. Publishing failures are logged since
+// the refund is already held.
+func (s *Service) emit(ctx context.Context, approval *Approval) {
+	if s.emitter == nil {
+		return
+	}
+	if err := s.emitter.Emit(ctx, "refunds", EventApprovalRequested, approval.ID, approval); err != nil {
+		log.Printf("Failed to emit approval request for %s: %v", approval.ID, err)
+	}
+}
+
+// overThreshold reports whether a refund was held for being over the
+// approval threshold
+func overThreshold(violations []Violation) bool {
+	for _, violation := range violations {
+		if violation.Dimension == DimensionRefund {
+			return true
+		}
+	}
+	return false
+}
+
+// anomalous reports whether a refund was held for its refund volume
+func anomalous(violations []Violation) bool {
+	for _, violation := range violations {
+		if violation.Dimension != DimensionRefund {
+			return true
+		}
+	}
+	return false
+}
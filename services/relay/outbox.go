@@ -0,0 +1,179 @@
+package relay
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"apis/payments/services/batching"
+	"apis/payments/services/config"
+	"apis/payments/services/events"
+	"apis/payments/services/tenancy"
+)
+
+// OutboxEvent is a relayed event waiting to be published, with the tenant
+// it was emitted for
+type OutboxEvent struct {
+	Event    *events.Event
+	TenantID string
+}
+
+// OutboxStore keeps relayed events until they are published. Events already
+// stored are ignored.
+type OutboxStore interface {
+	InsertOutboxEvent(ctx context.Context, event *OutboxEvent) error
+	ListOutboxEvents(ctx context.Context, limit int) ([]*OutboxEvent, error)
+	DeleteOutboxEvent(ctx context.Context, id string) error
+}
+
+// OutboxConfig controls the relay outbox
+type OutboxConfig struct {
+	Enabled  bool          // Store relayed events and publish them from workers
+	Interval time.Duration // How often workers publish stored events
+}
+
+// LoadOutboxConfig loads the outbox configuration from environment variables
+func LoadOutboxConfig() *OutboxConfig {
+	config := &OutboxConfig{
+		Interval: time.Duration(config.Int("RELAY_OUTBOX_INTERVAL_MS", 1000)) * time.Millisecond,
+	}
+	if enabled, err := strconv.ParseBool(os.Getenv("RELAY_OUTBOX_ENABLED")); err == nil {
+		config.Enabled = enabled
+	}
+	return config
+}
+
+// Outbox stores relayed events instead of publishing them while the webhook
+// is handled, so a broker outage doesn't fail webhooks. Workers publish
+// stored events oldest first in batches whose size and concurrency adapt to
+// how fast the publisher takes them. An event is published at least once;
+// consumers deduplicate by ID as they do for redelivered webhooks.
+type Outbox struct {
+	store     OutboxStore
+	publisher events.Publisher
+	config    *OutboxConfig
+	batches   *batching.Controller
+}
+
+// NewOutbox creates an outbox publishing stored events to publisher
+func NewOutbox(store OutboxStore, publisher events.Publisher, config *OutboxConfig) *Outbox {
+	return &Outbox{
+		store:     store,
+		publisher: publisher,
+		config:    config,
+		batches:   batching.NewController(batching.DefaultLimits()),
+	}
+}
+
+// UseBatchLimits sets the bounds publishing batches adapt within
+func (o *Outbox) UseBatchLimits(limits *batching.Limits) {
+	o.batches = batching.NewController(limits)
+}
+
+// BatchStats returns the current publishing batch size and concurrency
+func (o *Outbox) BatchStats() batching.Stats {
+	return o.batches.Stats()
+}
+
+// Enabled reports whether relayed events go through the outbox
+func (o *Outbox) Enabled() bool {
+	return o.config.Enabled
+}
+
+// Publish stores an event for the tenant it is emitted for
+func (o *Outbox) Publish(ctx context.Context, event *events.Event) error {
+	if err := o.store.InsertOutboxEvent(ctx, &OutboxEvent{Event: event, TenantID: tenancy.ID(ctx)}); err != nil {
+		return fmt.Errorf("failed to store event %s in outbox: %w", event.ID, err)
+	}
+	return nil
+}
+
+// Flush publishes stored events until none are left, returning how many
+// were published. It stops after a round of batches in which an event
+// failed to publish, leaving the rest for the next run.
+func (o *Outbox) Flush(ctx context.Context) (int, error) {
+	published := 0
+
+	for {
+		pending, err := o.store.ListOutboxEvents(ctx, o.batches.Size()*o.batches.Concurrency())
+		if err != nil {
+			return published, fmt.Errorf("failed to list outbox events: %w", err)
+		}
+		if len(pending) == 0 {
+			return published, nil
+		}
+
+		var failures atomic.Int64
+		processed, err := batching.Process(ctx, o.batches, pending, func(ctx context.Context, batch []*OutboxEvent) (int, error) {
+			failed, err := o.publish(ctx, batch)
+			failures.Add(int64(failed))
+			return failed, err
+		})
+		published += processed - int(failures.Load())
+		if err != nil {
+			return published, err
+		}
+		if failed := failures.Load(); failed > 0 {
+			return published, fmt.Errorf("%d outbox events failed to publish", failed)
+		}
+	}
+}
+
+// publish publishes a batch of stored events for their tenants, deleting
+// each once published, and returns how many failed
+func (o *Outbox) publish(ctx context.Context, batch []*OutboxEvent) (int, error) {
+	failed := 0
+	for _, stored := range batch {
+		if err := o.publisher.Publish(tenancy.WithTenant(ctx, stored.TenantID), stored.Event); err != nil {
+			log.Printf("Failed to publish outbox event %s: %v", stored.Event.ID, err)
+			failed++
+			continue
+		}
+		if err := o.store.DeleteOutboxEvent(ctx, stored.Event.ID); err != nil {
+			return failed, fmt.Errorf("failed to delete outbox event %s: %w", stored.Event.ID, err)
+		}
+	}
+	return failed, nil
+}
+
+// Start publishes stored events every interval when the outbox is enabled
+// and returns a func stopping it
+func (o *Outbox) Start() (stop func()) {
+	if !o.config.Enabled {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(o.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				published, err := o.Flush(context.Background())
+				if err != nil {
+					log.Printf("Outbox publish failed: %v", err)
+				}
+				if published > 0 {
+					stats := o.batches.Stats()
+					log.Printf("Published %d outbox events (batch size %d, concurrency %d)", published, stats.Size, stats.Concurrency)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
@@ -0,0 +1,208 @@
+package relay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"apis/payments/services"
+	"apis/payments/services/events"
+	"apis/payments/services/stripe"
+
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// Provider events relayed for each resource
+var (
+	chargeEvents = []stripego.EventType{
+		stripego.EventTypeChargePending,
+		stripego.EventTypeChargeSucceeded,
+		stripego.EventTypeChargeFailed,
+		stripego.EventTypeChargeCaptured,
+		stripego.EventTypeChargeExpired,
+		stripego.EventTypeChargeUpdated,
+		stripego.EventTypeChargeRefunded,
+	}
+	refundEvents = []stripego.EventType{
+		stripego.EventTypeRefundCreated,
+		stripego.EventTypeRefundUpdated,
+		stripego.EventTypeChargeRefundUpdated,
+	}
+	disputeEvents = []stripego.EventType{
+		stripego.EventTypeChargeDisputeCreated,
+		stripego.EventTypeChargeDisputeUpdated,
+		stripego.EventTypeChargeDisputeClosed,
+		stripego.EventTypeChargeDisputeFundsWithdrawn,
+		stripego.EventTypeChargeDisputeFundsReinstated,
+	}
+	invoiceEvents = []stripego.EventType{
+		stripego.EventTypeInvoiceCreated,
+		stripego.EventTypeInvoiceFinalized,
+		stripego.EventTypeInvoiceUpdated,
+		stripego.EventTypeInvoicePaid,
+		stripego.EventTypeInvoicePaymentFailed,
+		stripego.EventTypeInvoiceVoided,
+		stripego.EventTypeInvoiceMarkedUncollectible,
+		stripego.EventTypeInvoiceDeleted,
+	}
+)
+
+// Paddle and PayPal events relayed for charges and refunds, and the types
+// they are relayed as
+var (
+	paddleChargeEvents = map[string]string{
+		"transaction.completed":      "payments.charge.succeeded",
+		"transaction.payment_failed": "payments.charge.failed",
+		"transaction.updated":        "payments.charge.updated",
+	}
+	paddleRefundEvents = map[string]string{
+		"adjustment.created": "payments.refund.created",
+		"adjustment.updated": "payments.refund.updated",
+	}
+	paypalChargeEvents = map[string]string{
+		"PAYMENT.CAPTURE.PENDING":   "payments.charge.pending",
+		"PAYMENT.CAPTURE.COMPLETED": "payments.charge.succeeded",
+		"PAYMENT.CAPTURE.DENIED":    "payments.charge.failed",
+	}
+	paypalRefundEvents = map[string]string{
+		"PAYMENT.CAPTURE.REFUNDED": "payments.refund.created",
+	}
+)
+
+// Service re-publishes charge, refund, dispute and invoice changes made at
+// Stripe, and charge and refund changes made at Paddle and PayPal, as
+// CloudEvents carrying the normalized object, so downstream systems learn
+// about them without consuming provider webhooks
+type Service struct {
+	emitter *events.Emitter
+}
+
+// NewService creates a new relay service
+func NewService(emitter *events.Emitter) *Service {
+	return &Service{emitter: emitter}
+}
+
+// RegisterWebhookHandlers relays provider events. Register it after the
+// handlers that update local state, so an event is only published once the
+// change it describes has been stored. A failed publish fails the webhook,
+// which the provider then redelivers.
+func (s *Service) RegisterWebhookHandlers(webhooks *stripe.WebhookService) {
+	for _, eventType := range chargeEvents {
+		webhooks.On(eventType, func(ctx context.Context, event stripego.Event) error {
+			var charge stripego.Charge
+			if err := json.Unmarshal(event.Data.Raw, &charge); err != nil {
+				return fmt.Errorf("failed to parse charge: %w", err)
+			}
+
+			return s.relay(ctx, event, "charges", charge.ID, stripe.ConvertCharge(&charge))
+		})
+	}
+
+	for _, eventType := range refundEvents {
+		webhooks.On(eventType, func(ctx context.Context, event stripego.Event) error {
+			var refund stripego.Refund
+			if err := json.Unmarshal(event.Data.Raw, &refund); err != nil {
+				return fmt.Errorf("failed to parse refund: %w", err)
+			}
+
+			return s.relay(ctx, event, "refunds", refund.ID, stripe.ConvertRefund(&refund))
+		})
+	}
+
+	for _, eventType := range disputeEvents {
+		webhooks.On(eventType, func(ctx context.Context, event stripego.Event) error {
+			var dispute stripego.Dispute
+			if err := json.Unmarshal(event.Data.Raw, &dispute); err != nil {
+				return fmt.Errorf("failed to parse dispute: %w", err)
+			}
+
+			return s.relay(ctx, event, "disputes", dispute.ID, stripe.ConvertDispute(&dispute))
+		})
+	}
+
+	for _, eventType := range invoiceEvents {
+		webhooks.On(eventType, func(ctx context.Context, event stripego.Event) error {
+			var invoice stripego.Invoice
+			if err := json.Unmarshal(event.Data.Raw, &invoice); err != nil {
+				return fmt.Errorf("failed to parse invoice: %w", err)
+			}
+
+			return s.relay(ctx, event, "invoices", invoice.ID, stripe.ConvertInvoice(&invoice))
+		})
+	}
+}
+
+// RegisterPaddleHandlers relays Paddle transaction and adjustment events as
+// charge and refund events
+func (s *Service) RegisterPaddleHandlers(gateway *services.PaddleGateway) {
+	for eventType, relayedType := range paddleChargeEvents {
+		gateway.On(eventType, func(ctx context.Context, event services.PaddleEvent) error {
+			charge, err := event.Charge()
+			if err != nil {
+				return err
+			}
+
+			return s.emitter.Relay(ctx, event.EventID, event.OccurredAt, "charges", relayedType, charge.ID, charge)
+		})
+	}
+
+	for eventType, relayedType := range paddleRefundEvents {
+		gateway.On(eventType, func(ctx context.Context, event services.PaddleEvent) error {
+			refund, err := event.Refund()
+			if err != nil {
+				return err
+			}
+
+			return s.emitter.Relay(ctx, event.EventID, event.OccurredAt, "refunds", relayedType, refund.ID, refund)
+		})
+	}
+}
+
+// RegisterPayPalHandlers relays PayPal capture events as charge and refund
+// events. Captures are relayed as the order they belong to, which is what
+// the gateway calls a charge, fetched from PayPal.
+func (s *Service) RegisterPayPalHandlers(gateway *services.PayPalGateway) {
+	for eventType, relayedType := range paypalChargeEvents {
+		gateway.On(eventType, func(ctx context.Context, event services.PayPalEvent) error {
+			orderID, err := event.OrderID()
+			if err != nil {
+				return err
+			}
+			charge, err := gateway.GetCharge(ctx, orderID)
+			if err != nil {
+				return fmt.Errorf("failed to get order %s: %w", orderID, err)
+			}
+
+			return s.emitter.Relay(ctx, event.ID, event.CreateTime, "charges", relayedType, charge.ID, charge)
+		})
+	}
+
+	for eventType, relayedType := range paypalRefundEvents {
+		gateway.On(eventType, func(ctx context.Context, event services.PayPalEvent) error {
+			refund, err := event.Refund()
+			if err != nil {
+				return err
+			}
+
+			return s.emitter.Relay(ctx, event.ID, event.CreateTime, "refunds", relayedType, refund.ID, refund)
+		})
+	}
+}
+
+// relay publishes the normalized object from a provider event
+func (s *Service) relay(ctx context.Context, event stripego.Event, resource, subject string, data any) error {
+	return s.emitter.Relay(ctx, event.ID, time.Unix(event.Created, 0), resource, EventType(event.Type), subject, data)
+}
+
+// EventType returns the type a provider event is relayed as, e.g.
+// payments.charge.succeeded for charge.succeeded and payments.refund.updated
+// for charge.refund.updated
+func EventType(eventType stripego.EventType) string {
+	name := string(eventType)
+	if strings.HasPrefix(name, "charge.refund.") || strings.HasPrefix(name, "charge.dispute.") {
+		name = strings.TrimPrefix(name, "charge.")
+	}
+	return "payments." + name
+}
@@ -0,0 +1,109 @@
+package requestid
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Header is the HTTP header carrying a request's ID, accepted from callers
+// and echoed on every response
+const Header = "X-Request-ID"
+
+// MetadataKey is the gRPC metadata key carrying a call's ID
+const MetadataKey = "x-request-id"
+
+// Attribute is the span attribute holding the request ID
+const Attribute = "request.id"
+
+// maxLength caps the length of request IDs accepted from callers
+const maxLength = 128
+
+// requestIDKey is the context key of the request ID
+type requestIDKey struct{}
+
+// New generates a request ID
+func New() string {
+	return uuid.NewString()
+}
+
+// Valid reports whether a caller-supplied request ID can be propagated: it
+// must be non-empty, at most 128 characters and printable ASCII without
+// spaces, so it is safe to echo in headers and logs
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// Resolve returns a caller-supplied request ID when it is valid, or a new one
+func Resolve(id string) string {
+	if Valid(id) {
+		return id
+	}
+	return New()
+}
+
+// WithID returns a context carrying a request ID
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// FromContext returns the ID of the request a context serves
+func FromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok && id != ""
+}
+
+// ValueSetter stores request-scoped values, such as a *fasthttp.RequestCtx,
+// whose values are then visible through its context.Context
+type ValueSetter interface {
+	SetUserValue(key, value any)
+}
+
+// Bind makes a request's context carry a request ID
+func Bind(request ValueSetter, id string) {
+	request.SetUserValue(requestIDKey{}, id)
+}
+
+// Logf logs a line prefixed with the ID of the request ctx serves, if any
+func Logf(ctx context.Context, format string, args ...any) {
+	if id, ok := FromContext(ctx); ok {
+		log.Printf("[%s] %s", id, fmt.Sprintf(format, args...))
+		return
+	}
+	log.Printf(format, args...)
+}
+
+// SpanProcessor tags every span started within a request with its ID, so
+// the spans of one request can be found without knowing its trace ID
+type SpanProcessor struct{}
+
+// OnStart sets the request ID attribute from the parent context
+func (SpanProcessor) OnStart(parent context.Context, span sdktrace.ReadWriteSpan) {
+	if id, ok := FromContext(parent); ok {
+		span.SetAttributes(attribute.String(Attribute, id))
+	}
+}
+
+// OnEnd does nothing
+func (SpanProcessor) OnEnd(sdktrace.ReadOnlySpan) {}
+
+// Shutdown does nothing
+func (SpanProcessor) Shutdown(context.Context) error { return nil }
+
+// ForceFlush does nothing
+func (SpanProcessor) ForceFlush(context.Context) error { return nil }
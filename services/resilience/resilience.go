@@ -0,0 +1,275 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"apis/payments/services/config"
+)
+
+// ErrCircuitOpen is returned without calling the provider while an
+// operation's circuit breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// Config controls retries, timeouts and circuit breaking of provider calls
+type Config struct {
+	// Enabled wraps provider gateways in retries and circuit breakers
+	Enabled bool
+	// MaxAttempts is how many times a call is made before its error is returned
+	MaxAttempts int
+	// BaseDelay and MaxDelay bound the jittered exponential backoff between attempts
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// AttemptTimeout limits each attempt
+	AttemptTimeout time.Duration
+	// Budget limits a call including all its attempts and backoff
+	Budget time.Duration
+	// FailureThreshold is the number of consecutive transient failures that
+	// opens an operation's breaker
+	FailureThreshold int
+	// OpenDuration is how long a breaker stays open before letting a probe through
+	OpenDuration time.Duration
+}
+
+// LoadConfig loads resilience settings from environment variables
+func LoadConfig() *Config {
+	return &Config{
+		Enabled:          os.Getenv("GATEWAY_RESILIENCE_ENABLED") != "false",
+		MaxAttempts:      config.Int("GATEWAY_RETRY_MAX_ATTEMPTS", 3),
+		BaseDelay:        time.Duration(config.Int("GATEWAY_RETRY_BASE_DELAY_MS", 100)) * time.Millisecond,
+		MaxDelay:         time.Duration(config.Int("GATEWAY_RETRY_MAX_DELAY_MS", 2000)) * time.Millisecond,
+		AttemptTimeout:   time.Duration(config.Int("GATEWAY_ATTEMPT_TIMEOUT_MS", 10000)) * time.Millisecond,
+		Budget:           time.Duration(config.Int("GATEWAY_CALL_BUDGET_MS", 25000)) * time.Millisecond,
+		FailureThreshold: config.Int("GATEWAY_BREAKER_FAILURE_THRESHOLD", 5),
+		OpenDuration:     time.Duration(config.Int("GATEWAY_BREAKER_OPEN_SECONDS", 30)) * time.Second,
+	}
+}
+
+// Breaker states
+const (
+	// StateClosed lets calls through
+	StateClosed = "closed"
+	// StateOpen rejects calls with ErrCircuitOpen
+	StateOpen = "open"
+	// StateHalfOpen lets one probe call through to decide whether to close
+	StateHalfOpen = "half_open"
+)
+
+// BreakerStatus is a snapshot of one operation's breaker
+type BreakerStatus struct {
+	Operation           string     `json:"operation"`
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
+	Rejected            int64      `json:"rejected"`
+}
+
+// breaker trips after consecutive transient failures of an operation
+type breaker struct {
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool
+	rejected int64
+}
+
+// Policy runs provider calls with retries, timeout budgets and a circuit
+// breaker per operation, so a provider outage fails calls fast instead of
+// tying up every request until it times out
+type Policy struct {
+	config    *Config
+	transient func(error) bool
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+	jitter   func(limit time.Duration) time.Duration
+}
+
+// NewPolicy creates a policy. transient reports whether an error is worth
+// retrying and counts towards opening a breaker, e.g. a timeout or a 5xx
+// response; other errors, such as declines, pass straight through.
+func NewPolicy(config *Config, transient func(error) bool) *Policy {
+	if config == nil {
+		config = LoadConfig()
+	}
+
+	return &Policy{
+		config:    config,
+		transient: transient,
+		breakers:  make(map[string]*breaker),
+		jitter: func(limit time.Duration) time.Duration {
+			if limit <= 0 {
+				return 0
+			}
+			return time.Duration(rand.Int63n(int64(limit)))
+		},
+	}
+}
+
+// Do runs call for an operation. Transient failures of retryable calls are
+// retried with jittered exponential backoff while the budget allows; calls
+// that are not safe to repeat, such as creating a charge, are made once.
+func (p *Policy) Do(ctx context.Context, operation string, retryable bool, call func(ctx context.Context) error) error {
+	if p.config.Budget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.config.Budget)
+		defer cancel()
+	}
+
+	attempts := 1
+	if retryable && p.config.MaxAttempts > 1 {
+		attempts = p.config.MaxAttempts
+	}
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			if sleepErr := sleep(ctx, p.backoff(attempt)); sleepErr != nil {
+				return err
+			}
+		}
+
+		if err = p.allow(operation); err != nil {
+			return err
+		}
+		err = p.attempt(ctx, call)
+		p.record(operation, err)
+
+		if err == nil || !p.transient(err) || ctx.Err() != nil {
+			return err
+		}
+	}
+
+	return err
+}
+
+// Breakers returns the state of every operation's breaker
+func (p *Policy) Breakers() []BreakerStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	statuses := make([]BreakerStatus, 0, len(p.breakers))
+	for operation, b := range p.breakers {
+		p.refresh(b)
+		status := BreakerStatus{
+			Operation:           operation,
+			State:               b.state,
+			ConsecutiveFailures: b.failures,
+			Rejected:            b.rejected,
+		}
+		if b.state != StateClosed {
+			openedAt := b.openedAt
+			status.OpenedAt = &openedAt
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Operation < statuses[j].Operation })
+
+	return statuses
+}
+
+// Open returns the operations whose breakers are not closed
+func (p *Policy) Open() []string {
+	var open []string
+	for _, status := range p.Breakers() {
+		if status.State != StateClosed {
+			open = append(open, status.Operation)
+		}
+	}
+	return open
+}
+
+// attempt runs one attempt within the attempt timeout
+func (p *Policy) attempt(ctx context.Context, call func(ctx context.Context) error) error {
+	if p.config.AttemptTimeout <= 0 {
+		return call(ctx)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.config.AttemptTimeout)
+	defer cancel()
+	return call(ctx)
+}
+
+// backoff returns the delay before an attempt: full jitter over an
+// exponentially growing window capped at MaxDelay
+func (p *Policy) backoff(attempt int) time.Duration {
+	window := p.config.BaseDelay << (attempt - 1)
+	if window <= 0 || window > p.config.MaxDelay {
+		window = p.config.MaxDelay
+	}
+	return p.jitter(window)
+}
+
+// allow admits a call unless the operation's breaker is open. Once the open
+// period has passed, a single probe is let through.
+func (p *Policy) allow(operation string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	b := p.breaker(operation)
+	p.refresh(b)
+	switch {
+	case b.state == StateOpen, b.state == StateHalfOpen && b.probing:
+		b.rejected++
+		return ErrCircuitOpen
+	case b.state == StateHalfOpen:
+		b.probing = true
+	}
+
+	return nil
+}
+
+// record updates the operation's breaker with an attempt's outcome
+func (p *Policy) record(operation string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	b := p.breaker(operation)
+	b.probing = false
+	if err == nil || !p.transient(err) {
+		b.state = StateClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == StateHalfOpen || b.failures >= p.config.FailureThreshold {
+		b.state = StateOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// refresh moves an open breaker to half-open once its open period has passed
+func (p *Policy) refresh(b *breaker) {
+	if b.state == StateOpen && time.Since(b.openedAt) >= p.config.OpenDuration {
+		b.state = StateHalfOpen
+	}
+}
+
+// breaker returns an operation's breaker, creating it closed
+func (p *Policy) breaker(operation string) *breaker {
+	b, ok := p.breakers[operation]
+	if !ok {
+		b = &breaker{state: StateClosed}
+		p.breakers[operation] = b
+	}
+	return b
+}
+
+// sleep waits for d unless ctx ends first
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
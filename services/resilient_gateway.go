@@ -0,0 +1,290 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"apis/payments/services/resilience"
+
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// ResilientGateway decorates a gateway with the retries, timeout budgets and
+// per-operation circuit breakers of a resilience policy. Reads and
+// idempotent updates are retried on transient errors; creates and captures
+// are made once, since repeating them could charge or refund twice.
+type ResilientGateway struct {
+	gateway PaymentGateway
+	policy  *resilience.Policy
+}
+
+// resilientDisputeGateway is a ResilientGateway over a gateway that also
+// manages disputes
+type resilientDisputeGateway struct {
+	*ResilientGateway
+	disputes DisputeManager
+}
+
+// NewResilientGateway wraps a gateway in a policy. Breakers are keyed by
+// provider and operation, so gateways of one provider sharing a policy trip
+// together. The result implements DisputeManager when the gateway does.
+func NewResilientGateway(gateway PaymentGateway, policy *resilience.Policy) PaymentGateway {
+	resilient := &ResilientGateway{gateway: gateway, policy: policy}
+	if disputes, ok := gateway.(DisputeManager); ok {
+		return &resilientDisputeGateway{ResilientGateway: resilient, disputes: disputes}
+	}
+	return resilient
+}
+
+// NewResiliencePolicy creates a policy treating IsTransientError errors as
+// transient
+func NewResiliencePolicy(config *resilience.Config) *resilience.Policy {
+	return resilience.NewPolicy(config, IsTransientError)
+}
+
+// IsTransientError reports whether a provider error is likely to pass on
+// retry: network errors, timeouts, rate limiting and 5xx responses
+func IsTransientError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var stripeErr *stripego.Error
+	if errors.As(err, &stripeErr) {
+		return transientStatus(stripeErr.HTTPStatusCode)
+	}
+	var statusErr interface{ HTTPStatus() int }
+	if errors.As(err, &statusErr) {
+		return transientStatus(statusErr.HTTPStatus())
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// transientStatus reports whether a provider response status is worth retrying
+func transientStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// Unwrap returns the decorated gateway
+func (g *ResilientGateway) Unwrap() PaymentGateway {
+	return g.gateway
+}
+
+// GetProvider returns the decorated gateway's provider
+func (g *ResilientGateway) GetProvider() string {
+	return g.gateway.GetProvider()
+}
+
+// GetCapabilities returns the decorated gateway's capabilities
+func (g *ResilientGateway) GetCapabilities() GatewayCapabilities {
+	return g.gateway.GetCapabilities()
+}
+
+// CreateCustomer creates a customer, without retrying
+func (g *ResilientGateway) CreateCustomer(ctx context.Context, req CreateCustomerRequest) (*Customer, error) {
+	return callResilient(ctx, g, "CreateCustomer", false, func(ctx context.Context) (*Customer, error) {
+		return g.gateway.CreateCustomer(ctx, req)
+	})
+}
+
+// GetCustomer retrieves a customer
+func (g *ResilientGateway) GetCustomer(ctx context.Context, customerID string) (*Customer, error) {
+	return callResilient(ctx, g, "GetCustomer", true, func(ctx context.Context) (*Customer, error) {
+		return g.gateway.GetCustomer(ctx, customerID)
+	})
+}
+
+// UpdateCustomer updates a customer
+func (g *ResilientGateway) UpdateCustomer(ctx context.Context, customerID string, req UpdateCustomerRequest) (*Customer, error) {
+	return callResilient(ctx, g, "UpdateCustomer", true, func(ctx context.Context) (*Customer, error) {
+		return g.gateway.UpdateCustomer(ctx, customerID, req)
+	})
+}
+
+// DeleteCustomer deletes a customer
+func (g *ResilientGateway) DeleteCustomer(ctx context.Context, customerID string) error {
+	return g.policy.Do(ctx, g.operation("DeleteCustomer"), true, func(ctx context.Context) error {
+		return g.gateway.DeleteCustomer(ctx, customerID)
+	})
+}
+
+// ListCustomers lists customers
+func (g *ResilientGateway) ListCustomers(ctx context.Context, req ListCustomersRequest) (*CustomerList, error) {
+	return callResilient(ctx, g, "ListCustomers", true, func(ctx context.Context) (*CustomerList, error) {
+		return g.gateway.ListCustomers(ctx, req)
+	})
+}
+
+// AddPaymentMethod adds a payment method to a customer, without retrying
+func (g *ResilientGateway) AddPaymentMethod(ctx context.Context, customerID string, req AddPaymentMethodRequest) (*PaymentMethod, error) {
+	return callResilient(ctx, g, "AddPaymentMethod", false, func(ctx context.Context) (*PaymentMethod, error) {
+		return g.gateway.AddPaymentMethod(ctx, customerID, req)
+	})
+}
+
+// RemovePaymentMethod removes a payment method from a customer
+func (g *ResilientGateway) RemovePaymentMethod(ctx context.Context, customerID string, paymentMethodID string) error {
+	return g.policy.Do(ctx, g.operation("RemovePaymentMethod"), true, func(ctx context.Context) error {
+		return g.gateway.RemovePaymentMethod(ctx, customerID, paymentMethodID)
+	})
+}
+
+// ListPaymentMethods lists a customer's payment methods
+func (g *ResilientGateway) ListPaymentMethods(ctx context.Context, customerID string, req ListPaymentMethodsRequest) (*PaymentMethodList, error) {
+	return callResilient(ctx, g, "ListPaymentMethods", true, func(ctx context.Context) (*PaymentMethodList, error) {
+		return g.gateway.ListPaymentMethods(ctx, customerID, req)
+	})
+}
+
+// CreateCharge creates a charge, without retrying
+func (g *ResilientGateway) CreateCharge(ctx context.Context, req CreateChargeRequest) (*Charge, error) {
+	return callResilient(ctx, g, "CreateCharge", false, func(ctx context.Context) (*Charge, error) {
+		return g.gateway.CreateCharge(ctx, req)
+	})
+}
+
+// GetCharge retrieves a charge
+func (g *ResilientGateway) GetCharge(ctx context.Context, chargeID string) (*Charge, error) {
+	return callResilient(ctx, g, "GetCharge", true, func(ctx context.Context) (*Charge, error) {
+		return g.gateway.GetCharge(ctx, chargeID)
+	})
+}
+
+// UpdateCharge updates a charge
+func (g *ResilientGateway) UpdateCharge(ctx context.Context, chargeID string, req UpdateChargeRequest) (*Charge, error) {
+	return callResilient(ctx, g, "UpdateCharge", true, func(ctx context.Context) (*Charge, error) {
+		return g.gateway.UpdateCharge(ctx, chargeID, req)
+	})
+}
+
+// CaptureCharge captures an authorized charge, without retrying
+func (g *ResilientGateway) CaptureCharge(ctx context.Context, chargeID string, req CaptureChargeRequest) (*Charge, error) {
+	return callResilient(ctx, g, "CaptureCharge", false, func(ctx context.Context) (*Charge, error) {
+		return g.gateway.CaptureCharge(ctx, chargeID, req)
+	})
+}
+
+// VoidCharge releases an authorization, without retrying
+func (g *ResilientGateway) VoidCharge(ctx context.Context, chargeID string) (*Charge, error) {
+	return callResilient(ctx, g, "VoidCharge", false, func(ctx context.Context) (*Charge, error) {
+		return g.gateway.VoidCharge(ctx, chargeID)
+	})
+}
+
+// ListCharges lists charges
+func (g *ResilientGateway) ListCharges(ctx context.Context, req ListChargesRequest) (*ChargeList, error) {
+	return callResilient(ctx, g, "ListCharges", true, func(ctx context.Context) (*ChargeList, error) {
+		return g.gateway.ListCharges(ctx, req)
+	})
+}
+
+// CreateRefund creates a refund, without retrying
+func (g *ResilientGateway) CreateRefund(ctx context.Context, req CreateRefundRequest) (*Refund, error) {
+	return callResilient(ctx, g, "CreateRefund", false, func(ctx context.Context) (*Refund, error) {
+		return g.gateway.CreateRefund(ctx, req)
+	})
+}
+
+// GetRefund retrieves a refund
+func (g *ResilientGateway) GetRefund(ctx context.Context, refundID string) (*Refund, error) {
+	return callResilient(ctx, g, "GetRefund", true, func(ctx context.Context) (*Refund, error) {
+		return g.gateway.GetRefund(ctx, refundID)
+	})
+}
+
+// UpdateRefund updates a refund
+func (g *ResilientGateway) UpdateRefund(ctx context.Context, refundID string, req UpdateRefundRequest) (*Refund, error) {
+	return callResilient(ctx, g, "UpdateRefund", true, func(ctx context.Context) (*Refund, error) {
+		return g.gateway.UpdateRefund(ctx, refundID, req)
+	})
+}
+
+// ListRefunds lists refunds
+func (g *ResilientGateway) ListRefunds(ctx context.Context, req ListRefundsRequest) (*RefundList, error) {
+	return callResilient(ctx, g, "ListRefunds", true, func(ctx context.Context) (*RefundList, error) {
+		return g.gateway.ListRefunds(ctx, req)
+	})
+}
+
+// CreateSubscription creates a subscription, without retrying
+func (g *ResilientGateway) CreateSubscription(ctx context.Context, req CreateSubscriptionRequest) (*Subscription, error) {
+	return callResilient(ctx, g, "CreateSubscription", false, func(ctx context.Context) (*Subscription, error) {
+		return g.gateway.CreateSubscription(ctx, req)
+	})
+}
+
+// GetSubscription retrieves a subscription
+func (g *ResilientGateway) GetSubscription(ctx context.Context, subscriptionID string) (*Subscription, error) {
+	return callResilient(ctx, g, "GetSubscription", true, func(ctx context.Context) (*Subscription, error) {
+		return g.gateway.GetSubscription(ctx, subscriptionID)
+	})
+}
+
+// UpdateSubscription updates a subscription
+func (g *ResilientGateway) UpdateSubscription(ctx context.Context, subscriptionID string, req UpdateSubscriptionRequest) (*Subscription, error) {
+	return callResilient(ctx, g, "UpdateSubscription", true, func(ctx context.Context) (*Subscription, error) {
+		return g.gateway.UpdateSubscription(ctx, subscriptionID, req)
+	})
+}
+
+// CancelSubscription cancels a subscription
+func (g *ResilientGateway) CancelSubscription(ctx context.Context, subscriptionID string, req CancelSubscriptionRequest) (*Subscription, error) {
+	return callResilient(ctx, g, "CancelSubscription", true, func(ctx context.Context) (*Subscription, error) {
+		return g.gateway.CancelSubscription(ctx, subscriptionID, req)
+	})
+}
+
+// ListSubscriptions lists subscriptions
+func (g *ResilientGateway) ListSubscriptions(ctx context.Context, req ListSubscriptionsRequest) (*SubscriptionList, error) {
+	return callResilient(ctx, g, "ListSubscriptions", true, func(ctx context.Context) (*SubscriptionList, error) {
+		return g.gateway.ListSubscriptions(ctx, req)
+	})
+}
+
+// GetDispute retrieves a dispute
+func (g *resilientDisputeGateway) GetDispute(ctx context.Context, disputeID string) (*Dispute, error) {
+	return callResilient(ctx, g.ResilientGateway, "GetDispute", true, func(ctx context.Context) (*Dispute, error) {
+		return g.disputes.GetDispute(ctx, disputeID)
+	})
+}
+
+// ListDisputes lists disputes
+func (g *resilientDisputeGateway) ListDisputes(ctx context.Context, req ListDisputesRequest) (*DisputeList, error) {
+	return callResilient(ctx, g.ResilientGateway, "ListDisputes", true, func(ctx context.Context) (*DisputeList, error) {
+		return g.disputes.ListDisputes(ctx, req)
+	})
+}
+
+// AcceptDispute concedes a dispute
+func (g *resilientDisputeGateway) AcceptDispute(ctx context.Context, disputeID string) (*Dispute, error) {
+	return callResilient(ctx, g.ResilientGateway, "AcceptDispute", true, func(ctx context.Context) (*Dispute, error) {
+		return g.disputes.AcceptDispute(ctx, disputeID)
+	})
+}
+
+// SubmitDisputeEvidence adds evidence to a dispute, without retrying
+func (g *resilientDisputeGateway) SubmitDisputeEvidence(ctx context.Context, disputeID string, req SubmitDisputeEvidenceRequest) (*Dispute, error) {
+	return callResilient(ctx, g.ResilientGateway, "SubmitDisputeEvidence", false, func(ctx context.Context) (*Dispute, error) {
+		return g.disputes.SubmitDisputeEvidence(ctx, disputeID, req)
+	})
+}
+
+// operation returns the breaker key of one of the gateway's operations
+func (g *ResilientGateway) operation(name string) string {
+	return g.gateway.GetProvider() + "." + name
+}
+
+// callResilient runs a gateway call returning a value through the policy
+func callResilient[T any](ctx context.Context, g *ResilientGateway, name string, retryable bool, call func(ctx context.Context) (T, error)) (T, error) {
+	var result T
+	err := g.policy.Do(ctx, g.operation(name), retryable, func(ctx context.Context) error {
+		var err error
+		result, err = call(ctx)
+		return err
+	})
+	return result, err
+}
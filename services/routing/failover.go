@@ -0,0 +1,118 @@
+package routing
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FailoverRule sends charges matching a currency, card country and amount
+// range to a chain of providers, tried in order. Empty criteria match
+// anything; amounts are in the currency's minor unit and bounds of 0 are open.
+type FailoverRule struct {
+	Currency  string   `json:"currency,omitempty"`
+	Country   string   `json:"country,omitempty"`
+	MinAmount int64    `json:"min_amount,omitempty"`
+	MaxAmount int64    `json:"max_amount,omitempty"`
+	Providers []string `json:"providers"`
+}
+
+// Matches reports whether a charge falls under the rule
+func (r FailoverRule) Matches(currency, country string, amount int64) bool {
+	if r.Currency != "" && r.Currency != strings.ToLower(currency) {
+		return false
+	}
+	if r.Country != "" && r.Country != strings.ToLower(country) {
+		return false
+	}
+	if r.MinAmount > 0 && amount < r.MinAmount {
+		return false
+	}
+	if r.MaxAmount > 0 && amount > r.MaxAmount {
+		return false
+	}
+	return true
+}
+
+// ParseFailoverRules parses provider chains such as
+// "eur:*:*=adyen>stripe,usd:us:0-500000=stripe>square,*:*:*=stripe>paypal":
+// currency:country:amount=provider>provider, with * matching anything and
+// amount ranges written min-max, either side of which may be left out
+func ParseFailoverRules(raw string) ([]FailoverRule, error) {
+	var rules []FailoverRule
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		criteria, chain, ok := strings.Cut(entry, "=")
+		fields := strings.Split(criteria, ":")
+		if !ok || len(fields) != 3 {
+			return nil, fmt.Errorf("invalid failover rule %q: want \"currency:country:amount=provider>provider\"", entry)
+		}
+
+		rule := FailoverRule{
+			Currency: wildcard(fields[0]),
+			Country:  wildcard(fields[1]),
+		}
+		if amount := wildcard(fields[2]); amount != "" {
+			low, high, _ := strings.Cut(amount, "-")
+			var err error
+			if rule.MinAmount, err = parseBound(low); err != nil {
+				return nil, fmt.Errorf("invalid failover rule %q: %w", entry, err)
+			}
+			if rule.MaxAmount, err = parseBound(high); err != nil {
+				return nil, fmt.Errorf("invalid failover rule %q: %w", entry, err)
+			}
+		}
+		for _, provider := range strings.Split(chain, ">") {
+			if provider = strings.ToLower(strings.TrimSpace(provider)); provider != "" {
+				rule.Providers = append(rule.Providers, provider)
+			}
+		}
+		if len(rule.Providers) == 0 {
+			return nil, fmt.Errorf("invalid failover rule %q: no providers", entry)
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// Chain returns the providers a charge is tried at, in order, from the first
+// matching failover rule
+func (r *Router) Chain(currency, country string, amount int64) ([]string, bool) {
+	for _, rule := range r.config.Load().FailoverRules {
+		if rule.Matches(currency, country, amount) {
+			return rule.Providers, true
+		}
+	}
+	return nil, false
+}
+
+// FailoverRules returns the configured failover rules
+func (r *Router) FailoverRules() []FailoverRule {
+	return r.config.Load().FailoverRules
+}
+
+// wildcard lowercases a rule field, returning "" for *
+func wildcard(field string) string {
+	field = strings.ToLower(strings.TrimSpace(field))
+	if field == "*" {
+		return ""
+	}
+	return field
+}
+
+// parseBound parses one side of an amount range, "" being open
+func parseBound(value string) (int64, error) {
+	if value = strings.TrimSpace(value); value == "" {
+		return 0, nil
+	}
+	amount, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || amount < 0 {
+		return 0, fmt.Errorf("amount bound %q must be a non-negative integer", value)
+	}
+	return amount, nil
+}
@@ -0,0 +1,128 @@
+package routing
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Reasons a provider was chosen for a charge
+const (
+	ReasonCurrency  = "currency"  // A currency route sent the charge to a local provider
+	ReasonDefault   = "default"   // No route exists for the currency
+	ReasonFallback  = "fallback"  // A route exists but its provider is not configured
+	ReasonRequested = "requested" // The request selected the provider
+)
+
+// Config holds currency routes, settlement currencies and reporting settings
+type Config struct {
+	DefaultProvider      string
+	CurrencyRoutes       map[string]string  // Charge currency to provider
+	SettlementCurrencies map[string]string  // Provider to the currency it pays out in
+	BaseCurrency         string             // Currency consolidated reports are stated in
+	Rates                map[string]float64 // Units of base currency per unit of each currency
+	FailoverRules        []FailoverRule     // Provider chains for gateway charges; the first matching rule applies
+}
+
+// LoadConfig loads the routing configuration from environment variables.
+// Routes and settlement currencies are comma-separated key=value pairs, e.g.
+// ROUTING_CURRENCY_ROUTES=eur=adyen,brl=ebanx. Invalid ROUTING_FAILOVER_RULES
+// are logged and ignored.
+func LoadConfig() *Config {
+	config := &Config{
+		DefaultProvider:      "stripe",
+		CurrencyRoutes:       parsePairs(os.Getenv("ROUTING_CURRENCY_ROUTES")),
+		SettlementCurrencies: parsePairs(os.Getenv("PROVIDER_SETTLEMENT_CURRENCIES")),
+		BaseCurrency:         "usd",
+		Rates:                make(map[string]float64),
+	}
+
+	if provider := os.Getenv("ROUTING_DEFAULT_PROVIDER"); provider != "" {
+		config.DefaultProvider = strings.ToLower(provider)
+	}
+	if currency := os.Getenv("REPORTING_BASE_CURRENCY"); currency != "" {
+		config.BaseCurrency = strings.ToLower(currency)
+	}
+	for currency, value := range parsePairs(os.Getenv("REPORTING_FX_RATES")) {
+		if rate, err := strconv.ParseFloat(value, 64); err == nil && rate > 0 {
+			config.Rates[currency] = rate
+		}
+	}
+	rules, err := ParseFailoverRules(os.Getenv("ROUTING_FAILOVER_RULES"))
+	if err != nil {
+		log.Printf("Warning: ignoring ROUTING_FAILOVER_RULES: %v", err)
+	}
+	config.FailoverRules = rules
+
+	return config
+}
+
+// parsePairs parses comma-separated key=value pairs, lowercasing both sides
+func parsePairs(value string) map[string]string {
+	pairs := make(map[string]string)
+	for _, part := range strings.Split(value, ",") {
+		key, val, ok := strings.Cut(part, "=")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		val = strings.ToLower(strings.TrimSpace(val))
+		if key != "" && val != "" {
+			pairs[key] = val
+		}
+	}
+	return pairs
+}
+
+// Decision is the provider chosen for a charge
+type Decision struct {
+	Provider           string `json:"provider"`
+	Currency           string `json:"currency"`
+	SettlementCurrency string `json:"settlement_currency"`
+	Reason             string `json:"reason"`
+}
+
+// RoutedCharge is a charge and the routing decision it was made with
+type RoutedCharge struct {
+	ChargeID string
+	Amount   int64
+	Decision
+	CreatedAt time.Time
+}
+
+// Volume is the charge volume routed to one provider in one currency
+type Volume struct {
+	Provider           string `json:"provider"`
+	Currency           string `json:"currency"`
+	SettlementCurrency string `json:"settlement_currency"`
+	Count              int64  `json:"count"`
+	Amount             int64  `json:"amount"`
+	BaseAmount         int64  `json:"base_amount"`
+	Converted          bool   `json:"converted"` // False when no rate is configured for the currency
+}
+
+// ProviderReport is the routed volume for one provider
+type ProviderReport struct {
+	Provider   string    `json:"provider"`
+	Volumes    []*Volume `json:"volumes"`
+	BaseAmount int64     `json:"base_amount"`
+}
+
+// Report consolidates routed volume across providers in the base currency
+type Report struct {
+	BaseCurrency          string            `json:"base_currency"`
+	From                  time.Time         `json:"from"`
+	To                    time.Time         `json:"to"`
+	Providers             []*ProviderReport `json:"providers"`
+	BaseAmount            int64             `json:"base_amount"`
+	UnconvertedCurrencies []string          `json:"unconverted_currencies,omitempty"`
+}
+
+// Store persists routing decisions and summarizes routed volume
+type Store interface {
+	RecordRoutedCharge(ctx context.Context, charge *RoutedCharge) error
+	SummarizeRoutedCharges(ctx context.Context, from, to time.Time) ([]*Volume, error)
+}
@@ -0,0 +1,28 @@
+package routing
+
+import "context"
+
+// providerKey is the context key of the provider a request selected
+type providerKey struct{}
+
+// WithProvider returns a context whose charges are routed to a provider
+func WithProvider(ctx context.Context, provider string) context.Context {
+	return context.WithValue(ctx, providerKey{}, provider)
+}
+
+// SelectedProvider returns the provider a context's request selected
+func SelectedProvider(ctx context.Context) (string, bool) {
+	provider, ok := ctx.Value(providerKey{}).(string)
+	return provider, ok && provider != ""
+}
+
+// ValueSetter stores request-scoped values, such as a *fasthttp.RequestCtx,
+// whose values are then visible through its context.Context
+type ValueSetter interface {
+	SetUserValue(key, value any)
+}
+
+// Bind routes the charges of a request's context to a provider
+func Bind(request ValueSetter, provider string) {
+	request.SetUserValue(providerKey{}, provider)
+}
@@ -0,0 +1,183 @@
+package routing
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"apis/payments/services/money"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Router picks the provider for each charge by currency and reports routed
+// volume across providers
+type Router struct {
+	store     Store
+	config    atomic.Pointer[Config]
+	providers map[string]bool
+	tracer    trace.Tracer
+}
+
+// NewRouter creates a new currency router
+func NewRouter(store Store, config *Config) *Router {
+	r := &Router{
+		store:     store,
+		providers: make(map[string]bool),
+		tracer:    otel.Tracer("payments.routing"),
+	}
+	r.config.Store(config)
+	return r
+}
+
+// Reconfigure replaces the routes, settlement currencies, reporting rates
+// and failover rules
+func (r *Router) Reconfigure(config *Config) {
+	r.config.Store(config)
+}
+
+// RegisterProvider marks a provider as able to take charges. Currency routes
+// to providers that are not registered fall back to the default provider.
+func (r *Router) RegisterProvider(name string) {
+	r.providers[strings.ToLower(name)] = true
+}
+
+// Select returns the provider a charge in the given currency is routed to
+func (r *Router) Select(currency string) *Decision {
+	currency = strings.ToLower(currency)
+	config := r.config.Load()
+	decision := &Decision{
+		Provider: config.DefaultProvider,
+		Currency: currency,
+		Reason:   ReasonDefault,
+	}
+
+	if provider, ok := config.CurrencyRoutes[currency]; ok {
+		if r.providers[provider] {
+			decision.Provider = provider
+			decision.Reason = ReasonCurrency
+		} else {
+			decision.Reason = ReasonFallback
+		}
+	}
+
+	decision.SettlementCurrency = r.SettlementCurrency(decision.Provider, currency)
+	return decision
+}
+
+// Route returns the provider a charge is routed to: the provider its request
+// selected, if any, or the one its currency is routed to
+func (r *Router) Route(ctx context.Context, currency string) *Decision {
+	provider, ok := SelectedProvider(ctx)
+	if !ok {
+		return r.Select(currency)
+	}
+
+	currency = strings.ToLower(currency)
+	return &Decision{
+		Provider:           provider,
+		Currency:           currency,
+		SettlementCurrency: r.SettlementCurrency(provider, currency),
+		Reason:             ReasonRequested,
+	}
+}
+
+// SettlementCurrency returns the currency a provider pays out in. Providers
+// without a configured settlement currency settle in the charge currency.
+func (r *Router) SettlementCurrency(provider, currency string) string {
+	if settlement, ok := r.config.Load().SettlementCurrencies[provider]; ok {
+		return settlement
+	}
+	return currency
+}
+
+// Record stores the routing decision made for a charge
+func (r *Router) Record(ctx context.Context, chargeID string, amount int64, decision *Decision) error {
+	ctx, span := r.tracer.Start(ctx, "Record")
+	defer span.End()
+
+	return r.store.RecordRoutedCharge(ctx, &RoutedCharge{
+		ChargeID: chargeID,
+		Amount:   amount,
+		Decision: *decision,
+	})
+}
+
+// Report consolidates the volume routed to each provider between from and to
+// in the base currency. Currencies without a configured rate are listed but
+// left out of the base totals.
+func (r *Router) Report(ctx context.Context, from, to time.Time) (*Report, error) {
+	ctx, span := r.tracer.Start(ctx, "Report")
+	defer span.End()
+
+	if !to.After(from) {
+		return nil, fmt.Errorf("report end must be after its start")
+	}
+
+	volumes, err := r.store.SummarizeRoutedCharges(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		BaseCurrency: r.BaseCurrency(),
+		From:         from,
+		To:           to,
+		Providers:    []*ProviderReport{},
+	}
+	byProvider := make(map[string]*ProviderReport)
+	unconverted := make(map[string]bool)
+
+	for _, volume := range volumes {
+		volume.BaseAmount, volume.Converted = r.ToBase(volume.Amount, volume.Currency)
+
+		provider, ok := byProvider[volume.Provider]
+		if !ok {
+			provider = &ProviderReport{Provider: volume.Provider}
+			byProvider[volume.Provider] = provider
+			report.Providers = append(report.Providers, provider)
+		}
+		provider.Volumes = append(provider.Volumes, volume)
+
+		if !volume.Converted {
+			unconverted[volume.Currency] = true
+			continue
+		}
+		provider.BaseAmount += volume.BaseAmount
+		report.BaseAmount += volume.BaseAmount
+	}
+
+	for currency := range unconverted {
+		report.UnconvertedCurrencies = append(report.UnconvertedCurrencies, currency)
+	}
+	sort.Strings(report.UnconvertedCurrencies)
+
+	return report, nil
+}
+
+// BaseCurrency returns the currency consolidated amounts are stated in
+func (r *Router) BaseCurrency() string {
+	return r.config.Load().BaseCurrency
+}
+
+// ToBase converts a minor-unit amount to the base currency's minor units
+func (r *Router) ToBase(amount int64, currency string) (int64, bool) {
+	currency = strings.ToLower(currency)
+	config := r.config.Load()
+	if currency == config.BaseCurrency {
+		return amount, true
+	}
+
+	rate, ok := config.Rates[currency]
+	if !ok {
+		return 0, false
+	}
+
+	major := float64(amount) / math.Pow10(money.Exponent(currency))
+	return int64(math.Round(major * rate * math.Pow10(money.Exponent(config.BaseCurrency)))), true
+}
@@ -0,0 +1,53 @@
+package runmode
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Mode is the role an instance of the binary runs as
+type Mode string
+
+// Roles the binary can run as
+const (
+	// API serves the public API and provider webhooks. It keeps no state
+	// between requests, so it scales horizontally behind a load balancer.
+	API Mode = "api"
+	// Worker runs background jobs such as schedulers, catch-up and
+	// consumers, and serves only health checks
+	Worker Mode = "worker"
+	// All runs both roles in one process, for single-instance deployments
+	All Mode = "all"
+)
+
+// ErrInvalidMode is returned for an unknown RUN_MODE
+var ErrInvalidMode = errors.New("invalid run mode")
+
+// Parse parses a run mode, defaulting to All when it is empty
+func Parse(value string) (Mode, error) {
+	switch mode := Mode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case "":
+		return All, nil
+	case API, Worker, All:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("%w: %q, expected api, worker or all", ErrInvalidMode, value)
+	}
+}
+
+// Load reads the run mode from RUN_MODE
+func Load() (Mode, error) {
+	return Parse(os.Getenv("RUN_MODE"))
+}
+
+// ServesAPI reports whether the instance serves the API and webhooks
+func (m Mode) ServesAPI() bool {
+	return m == API || m == All
+}
+
+// RunsWorkers reports whether the instance runs background jobs
+func (m Mode) RunsWorkers() bool {
+	return m == Worker || m == All
+}
@@ -0,0 +1,110 @@
+package simulator
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Outcomes a test card triggers when charged
+const (
+	OutcomeSucceeded      = "succeeded"
+	OutcomeDeclined       = "declined"
+	OutcomeActionRequired = "requires_action" // 3D Secure authentication is needed
+	OutcomeDisputed       = "disputed"        // Succeeds, then is disputed as fraudulent
+	OutcomeInquiry        = "inquiry"         // Succeeds, then the bank opens an inquiry
+)
+
+// Card is a test card number and what charging it does
+type Card struct {
+	Number      string `json:"number"`
+	Brand       string `json:"brand"`
+	Outcome     string `json:"outcome"`
+	DeclineCode string `json:"decline_code,omitempty"`
+	Description string `json:"description"`
+}
+
+// Last4 returns the card number's last four digits
+func (c Card) Last4() string {
+	return c.Number[len(c.Number)-4:]
+}
+
+// cards are the numbers with a set outcome, following Stripe's test cards so
+// integrators can reuse the numbers they know. Other numbers passing the
+// Luhn check succeed.
+var cards = []Card{
+	{Number: "4242424242424242", Brand: "visa", Outcome: OutcomeSucceeded, Description: "Succeeds"},
+	{Number: "5555555555554444", Brand: "mastercard", Outcome: OutcomeSucceeded, Description: "Succeeds"},
+	{Number: "378282246310005", Brand: "amex", Outcome: OutcomeSucceeded, Description: "Succeeds"},
+	{Number: "4000000000000002", Brand: "visa", Outcome: OutcomeDeclined, DeclineCode: "generic_decline", Description: "Declined"},
+	{Number: "4000000000009995", Brand: "visa", Outcome: OutcomeDeclined, DeclineCode: "insufficient_funds", Description: "Declined for insufficient funds"},
+	{Number: "4000000000009987", Brand: "visa", Outcome: OutcomeDeclined, DeclineCode: "lost_card", Description: "Declined as lost"},
+	{Number: "4000000000009979", Brand: "visa", Outcome: OutcomeDeclined, DeclineCode: "stolen_card", Description: "Declined as stolen"},
+	{Number: "4000000000000069", Brand: "visa", Outcome: OutcomeDeclined, DeclineCode: "expired_card", Description: "Declined as expired"},
+	{Number: "4000000000000127", Brand: "visa", Outcome: OutcomeDeclined, DeclineCode: "incorrect_cvc", Description: "Declined for an incorrect CVC"},
+	{Number: "4000000000000119", Brand: "visa", Outcome: OutcomeDeclined, DeclineCode: "processing_error", Description: "Declined with a processing error, which failover retries elsewhere"},
+	{Number: "4000000000003220", Brand: "visa", Outcome: OutcomeActionRequired, Description: "Requires 3D Secure authentication"},
+	{Number: "4000000000000259", Brand: "visa", Outcome: OutcomeDisputed, Description: "Succeeds, then is disputed as fraudulent"},
+	{Number: "4000000000001976", Brand: "visa", Outcome: OutcomeInquiry, Description: "Succeeds, then the bank opens an inquiry"},
+}
+
+// Cards returns the test cards with a set outcome
+func Cards() []Card {
+	return append([]Card(nil), cards...)
+}
+
+// Lookup returns the test card for a number, which may contain spaces or
+// dashes. Numbers without a set outcome succeed if they pass the Luhn check.
+func Lookup(number string) (Card, error) {
+	number = strings.NewReplacer(" ", "", "-", "").Replace(number)
+	for _, card := range cards {
+		if card.Number == number {
+			return card, nil
+		}
+	}
+
+	if !validNumber(number) {
+		return Card{}, &Error{Code: CodeInvalidNumber, Message: "card number is not valid", Status: http.StatusBadRequest}
+	}
+	return Card{Number: number, Brand: brand(number), Outcome: OutcomeSucceeded, Description: "Succeeds"}, nil
+}
+
+// validNumber reports whether a card number has 12 to 19 digits and passes
+// the Luhn check
+func validNumber(number string) bool {
+	if len(number) < 12 || len(number) > 19 {
+		return false
+	}
+
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		digit := int(number[i] - '0')
+		if digit < 0 || digit > 9 {
+			return false
+		}
+		if double {
+			if digit *= 2; digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// brand guesses a card's brand from its leading digits
+func brand(number string) string {
+	switch {
+	case strings.HasPrefix(number, "4"):
+		return "visa"
+	case strings.HasPrefix(number, "5"), strings.HasPrefix(number, "2"):
+		return "mastercard"
+	case strings.HasPrefix(number, "34"), strings.HasPrefix(number, "37"):
+		return "amex"
+	case strings.HasPrefix(number, "6"):
+		return "discover"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,263 @@
+package simulator
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"apis/payments/services/tenancy"
+
+	"github.com/google/uuid"
+)
+
+// Provider is the name the simulator is registered as
+const Provider = "simulator"
+
+// Error codes of simulated failures
+const (
+	CodeCardDeclined   = "card_declined"
+	CodeInvalidNumber  = "invalid_number"
+	CodeInvalidRequest = "invalid_request"
+	CodeNotFound       = "resource_missing"
+)
+
+// Error is a simulated provider error. Declines carry the decline code of
+// the test card that triggered them.
+type Error struct {
+	Code        string `json:"code"`
+	DeclineCode string `json:"decline_code,omitempty"`
+	Message     string `json:"message"`
+	Status      int    `json:"-"`
+}
+
+func (e *Error) Error() string {
+	if e.DeclineCode != "" {
+		return fmt.Sprintf("%s (%s)", e.Message, e.DeclineCode)
+	}
+	return e.Message
+}
+
+// HTTPStatus returns the status the provider would answer with
+func (e *Error) HTTPStatus() int {
+	return e.Status
+}
+
+// GetDeclineCode returns why the card was declined, if it was
+func (e *Error) GetDeclineCode() string {
+	return e.DeclineCode
+}
+
+// NotFound returns the error for a missing object
+func NotFound(kind, id string) error {
+	return &Error{Code: CodeNotFound, Message: fmt.Sprintf("no such %s: %s", kind, id), Status: http.StatusNotFound}
+}
+
+// Invalid returns the error for a request the provider would reject
+func Invalid(message string) error {
+	return &Error{Code: CodeInvalidRequest, Message: message, Status: http.StatusBadRequest}
+}
+
+// Decline returns the error for a test card that is declined
+func Decline(card Card) error {
+	return &Error{Code: CodeCardDeclined, DeclineCode: card.DeclineCode, Message: "your card was declined", Status: http.StatusPaymentRequired}
+}
+
+// Config controls the simulator
+type Config struct {
+	Enabled       bool
+	Latency       time.Duration // Added to every call
+	LatencyJitter time.Duration // Up to this much more is added at random
+}
+
+// LoadConfig loads the simulator configuration from environment variables
+func LoadConfig() *Config {
+	config := &Config{}
+
+	if enabled, err := strconv.ParseBool(os.Getenv("SIMULATOR_ENABLED")); err == nil {
+		config.Enabled = enabled
+	}
+	if ms, err := strconv.Atoi(os.Getenv("SIMULATOR_LATENCY_MS")); err == nil && ms >= 0 {
+		config.Latency = time.Duration(ms) * time.Millisecond
+	}
+	if ms, err := strconv.Atoi(os.Getenv("SIMULATOR_LATENCY_JITTER_MS")); err == nil && ms >= 0 {
+		config.LatencyJitter = time.Duration(ms) * time.Millisecond
+	}
+
+	return config
+}
+
+// Object is a stored simulator object, such as a charge, kept as JSON
+type Object struct {
+	TenantID  string
+	ID        string
+	Kind      string
+	ParentID  string // e.g. the customer of a payment method
+	Data      json.RawMessage
+	CreatedAt time.Time
+}
+
+// Store persists simulator objects. Lists are newest first and continue
+// after the object whose ID is the cursor. Missing objects return
+// sql.ErrNoRows.
+type Store interface {
+	SaveSimulatorObject(ctx context.Context, object *Object) error
+	GetSimulatorObject(ctx context.Context, tenantID, kind, id string) (*Object, error)
+	ListSimulatorObjects(ctx context.Context, tenantID, kind, parentID, cursor string, limit int) ([]*Object, error)
+	DeleteSimulatorObject(ctx context.Context, tenantID, kind, id string) (bool, error)
+}
+
+// Latency is the delay added to every call
+type Latency struct {
+	LatencyMS int64 `json:"latency_ms"`
+	JitterMS  int64 `json:"jitter_ms"`
+}
+
+// Simulator keeps the state of the simulated provider for each tenant and
+// delays calls as a real provider would
+type Simulator struct {
+	store Store
+
+	mu      sync.Mutex
+	latency time.Duration
+	jitter  time.Duration
+}
+
+// New creates a simulator
+func New(store Store, config *Config) *Simulator {
+	return &Simulator{
+		store:   store,
+		latency: config.Latency,
+		jitter:  config.LatencyJitter,
+	}
+}
+
+// Latency returns the delay added to calls
+func (s *Simulator) Latency() Latency {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return Latency{LatencyMS: s.latency.Milliseconds(), JitterMS: s.jitter.Milliseconds()}
+}
+
+// SetLatency changes the delay added to calls, e.g. to test client timeouts
+func (s *Simulator) SetLatency(latency Latency) error {
+	if latency.LatencyMS < 0 || latency.JitterMS < 0 {
+		return Invalid("latency must not be negative")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = time.Duration(latency.LatencyMS) * time.Millisecond
+	s.jitter = time.Duration(latency.JitterMS) * time.Millisecond
+	return nil
+}
+
+// Delay waits out the injected latency, or until the context is done
+func (s *Simulator) Delay(ctx context.Context) error {
+	s.mu.Lock()
+	delay := s.latency
+	if s.jitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(s.jitter) + 1))
+	}
+	s.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// NewID returns an ID for a new object
+func NewID(prefix string) string {
+	return prefix + "_sim_" + uuid.New().String()[:24]
+}
+
+// Save stores an object for the tenant the context acts for
+func (s *Simulator) Save(ctx context.Context, kind, id, parentID string, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode simulator %s: %w", kind, err)
+	}
+
+	return s.store.SaveSimulatorObject(ctx, &Object{
+		TenantID: tenancy.ID(ctx),
+		ID:       id,
+		Kind:     kind,
+		ParentID: parentID,
+		Data:     data,
+	})
+}
+
+// Load reads an object of the tenant the context acts for into value
+func (s *Simulator) Load(ctx context.Context, kind, id string, value any) error {
+	object, err := s.store.GetSimulatorObject(ctx, tenancy.ID(ctx), kind, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return NotFound(kind, id)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(object.Data, value); err != nil {
+		return fmt.Errorf("failed to decode simulator %s: %w", kind, err)
+	}
+	return nil
+}
+
+// Delete removes an object of the tenant the context acts for
+func (s *Simulator) Delete(ctx context.Context, kind, id string) error {
+	deleted, err := s.store.DeleteSimulatorObject(ctx, tenancy.ID(ctx), kind, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return NotFound(kind, id)
+	}
+	return nil
+}
+
+// List reads a page of the tenant's objects of a kind, newest first,
+// optionally only those of a parent. The cursor continuing the list is
+// returned when more objects follow.
+func List[T any](ctx context.Context, s *Simulator, kind, parentID, cursor string, limit int) ([]T, string, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	objects, err := s.store.ListSimulatorObjects(ctx, tenancy.ID(ctx), kind, parentID, cursor, limit+1)
+	if err != nil {
+		return nil, "", err
+	}
+	nextCursor := ""
+	if len(objects) > limit {
+		objects = objects[:limit]
+		nextCursor = objects[limit-1].ID
+	}
+
+	values := make([]T, 0, len(objects))
+	for _, object := range objects {
+		var value T
+		if err := json.Unmarshal(object.Data, &value); err != nil {
+			return nil, "", fmt.Errorf("failed to decode simulator %s: %w", kind, err)
+		}
+		values = append(values, value)
+	}
+	return values, nextCursor, nil
+}
@@ -0,0 +1,911 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"apis/payments/services/receipts"
+	"apis/payments/services/simulator"
+)
+
+// Kinds of objects the simulator stores
+const (
+	simulatorCustomer      = "customer"
+	simulatorPaymentMethod = "payment_method"
+	simulatorCharge        = "charge"
+	simulatorRefund        = "refund"
+	simulatorDispute       = "dispute"
+	simulatorSubscription  = "subscription"
+)
+
+// simulatorAuthorizationWindow is how long simulated authorizations stay
+// capturable, as for Stripe card payments
+const simulatorAuthorizationWindow = 7 * 24 * time.Hour
+
+// simulatorEvidenceWindow is how long a simulated dispute can be answered
+const simulatorEvidenceWindow = 7 * 24 * time.Hour
+
+// simulatedPaymentMethod is a saved card and the test card number behind it
+type simulatedPaymentMethod struct {
+	PaymentMethod
+	Number string `json:"number"`
+}
+
+// simulatedCharge is a charge, the test card it was made with and what has
+// been captured and refunded from it
+type simulatedCharge struct {
+	Charge
+	Card           simulator.Card `json:"card"`
+	Capture        bool           `json:"capture"`
+	AmountCaptured int64          `json:"amount_captured"`
+	AmountRefunded int64          `json:"amount_refunded"`
+}
+
+// SimulatorGateway is a provider that behaves like a real one without
+// calling out, for integrators testing without provider sandbox keys. The
+// test card a charge is made with decides its outcome; objects are stored
+// per tenant and every call is delayed by the injected latency.
+type SimulatorGateway struct {
+	simulator *simulator.Simulator
+}
+
+// NewSimulatorGateway creates a simulator gateway
+func NewSimulatorGateway(sim *simulator.Simulator) *SimulatorGateway {
+	return &SimulatorGateway{simulator: sim}
+}
+
+// Latency returns the latency added to calls
+func (g *SimulatorGateway) Latency() simulator.Latency {
+	return g.simulator.Latency()
+}
+
+// SetLatency changes the latency added to calls
+func (g *SimulatorGateway) SetLatency(latency simulator.Latency) error {
+	return g.simulator.SetLatency(latency)
+}
+
+func (g *SimulatorGateway) GetProvider() string {
+	return simulator.Provider
+}
+
+func (g *SimulatorGateway) GetCapabilities() GatewayCapabilities {
+	return GatewayCapabilities{
+		SupportsCustomers:     true,
+		SupportsCharges:       true,
+		SupportsRefunds:       true,
+		SupportsSubscriptions: true,
+		SupportsDisputes:      true,
+		MinChargeAmount:       1,
+		AuthorizationWindow:   simulatorAuthorizationWindow,
+	}
+}
+
+// CreateCustomer creates a simulated customer
+func (g *SimulatorGateway) CreateCustomer(ctx context.Context, req CreateCustomerRequest) (*Customer, error) {
+	if err := g.simulator.Delay(ctx); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	id := simulator.NewID("cus")
+	customer := &Customer{
+		ID:         id,
+		Email:      req.Email,
+		Name:       req.Name,
+		Phone:      req.Phone,
+		Address:    req.Address,
+		Metadata:   req.Metadata,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		ProviderID: id,
+		Provider:   simulator.Provider,
+	}
+	if err := g.simulator.Save(ctx, simulatorCustomer, id, "", customer); err != nil {
+		return nil, err
+	}
+
+	return customer, nil
+}
+
+func (g *SimulatorGateway) GetCustomer(ctx context.Context, customerID string) (*Customer, error) {
+	if err := g.simulator.Delay(ctx); err != nil {
+		return nil, err
+	}
+
+	var customer Customer
+	if err := g.simulator.Load(ctx, simulatorCustomer, customerID, &customer); err != nil {
+		return nil, err
+	}
+	return &customer, nil
+}
+
+func (g *SimulatorGateway) UpdateCustomer(ctx context.Context, customerID string, req UpdateCustomerRequest) (*Customer, error) {
+	customer, err := g.GetCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Email != "" {
+		customer.Email = req.Email
+	}
+	if req.Name != "" {
+		customer.Name = req.Name
+	}
+	if req.Phone != "" {
+		customer.Phone = req.Phone
+	}
+	if req.Address != nil {
+		customer.Address = req.Address
+	}
+	if req.Metadata != nil {
+		customer.Metadata = mergeSimulatorMetadata(customer.Metadata, req.Metadata)
+	}
+	customer.UpdatedAt = time.Now().UTC()
+
+	if err := g.simulator.Save(ctx, simulatorCustomer, customer.ID, "", customer); err != nil {
+		return nil, err
+	}
+	return customer, nil
+}
+
+func (g *SimulatorGateway) DeleteCustomer(ctx context.Context, customerID string) error {
+	if err := g.simulator.Delay(ctx); err != nil {
+		return err
+	}
+	return g.simulator.Delete(ctx, simulatorCustomer, customerID)
+}
+
+// ListCustomers lists customers, newest first. Pages filtered by email may
+// hold fewer customers than the limit.
+func (g *SimulatorGateway) ListCustomers(ctx context.Context, req ListCustomersRequest) (*CustomerList, error) {
+	if err := g.simulator.Delay(ctx); err != nil {
+		return nil, err
+	}
+
+	customers, nextCursor, err := simulator.List[*Customer](ctx, g.simulator, simulatorCustomer, "", req.Cursor, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+	if req.Email != "" {
+		matching := customers[:0]
+		for _, customer := range customers {
+			if strings.EqualFold(customer.Email, req.Email) {
+				matching = append(matching, customer)
+			}
+		}
+		customers = matching
+	}
+
+	return &CustomerList{
+		Customers:  customers,
+		Total:      len(customers),
+		HasMore:    nextCursor != "",
+		NextCursor: nextCursor,
+	}, nil
+}
+
+// AddPaymentMethod saves a test card for a customer. The card number is
+// given as the number metadata value and is not returned.
+func (g *SimulatorGateway) AddPaymentMethod(ctx context.Context, customerID string, req AddPaymentMethodRequest) (*PaymentMethod, error) {
+	if req.Type != "" && req.Type != "card" {
+		return nil, simulator.Invalid("the simulator only saves cards")
+	}
+	number, _ := req.Metadata["number"].(string)
+	if number == "" {
+		return nil, simulator.Invalid("metadata number is required to save a simulator card")
+	}
+	card, err := simulator.Lookup(number)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := g.GetCustomer(ctx, customerID); err != nil {
+		return nil, err
+	}
+
+	expMonth, expYear := 12, time.Now().Year()+3
+	if req.Card != nil && req.Card.ExpMonth > 0 && req.Card.ExpYear > 0 {
+		expMonth, expYear = req.Card.ExpMonth, req.Card.ExpYear
+	}
+	metadata := make(map[string]interface{}, len(req.Metadata))
+	for key, value := range req.Metadata {
+		if key != "number" {
+			metadata[key] = value
+		}
+	}
+
+	id := simulator.NewID("pm")
+	method := &simulatedPaymentMethod{
+		PaymentMethod: PaymentMethod{
+			ID:         id,
+			CustomerID: customerID,
+			Type:       "card",
+			Card: &Card{
+				Brand:       card.Brand,
+				Last4:       card.Last4(),
+				ExpMonth:    expMonth,
+				ExpYear:     expYear,
+				Fingerprint: simulatorFingerprint(card.Number),
+				Country:     "US",
+			},
+			Metadata:   metadata,
+			CreatedAt:  time.Now().UTC(),
+			ProviderID: id,
+			Provider:   simulator.Provider,
+		},
+		Number: card.Number,
+	}
+	if err := g.simulator.Save(ctx, simulatorPaymentMethod, id, customerID, method); err != nil {
+		return nil, err
+	}
+
+	return &method.PaymentMethod, nil
+}
+
+func (g *SimulatorGateway) RemovePaymentMethod(ctx context.Context, customerID string, paymentMethodID string) error {
+	if err := g.simulator.Delay(ctx); err != nil {
+		return err
+	}
+
+	var method simulatedPaymentMethod
+	if err := g.simulator.Load(ctx, simulatorPaymentMethod, paymentMethodID, &method); err != nil {
+		return err
+	}
+	if method.CustomerID != customerID {
+		return simulator.NotFound(simulatorPaymentMethod, paymentMethodID)
+	}
+	return g.simulator.Delete(ctx, simulatorPaymentMethod, paymentMethodID)
+}
+
+func (g *SimulatorGateway) ListPaymentMethods(ctx context.Context, customerID string, req ListPaymentMethodsRequest) (*PaymentMethodList, error) {
+	if err := g.simulator.Delay(ctx); err != nil {
+		return nil, err
+	}
+
+	methods, nextCursor, err := simulator.List[*simulatedPaymentMethod](ctx, g.simulator, simulatorPaymentMethod, customerID, req.Cursor, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	paymentMethods := make([]*PaymentMethod, len(methods))
+	for i, method := range methods {
+		paymentMethods[i] = &method.PaymentMethod
+	}
+	return &PaymentMethodList{
+		PaymentMethods: paymentMethods,
+		HasMore:        nextCursor != "",
+		NextCursor:     nextCursor,
+	}, nil
+}
+
+// CreateCharge charges a saved simulator card, or a test card number given
+// as the payment method. Declined cards store a failed charge and return
+// the card's decline code; cards requiring 3D Secure leave the charge
+// requires_action until AuthenticateCharge completes it.
+func (g *SimulatorGateway) CreateCharge(ctx context.Context, req CreateChargeRequest) (*Charge, error) {
+	if req.Amount <= 0 {
+		return nil, simulator.Invalid("amount must be positive")
+	}
+	if req.Currency == "" {
+		return nil, simulator.Invalid("currency is required")
+	}
+	if err := receipts.ValidateDescriptor(simulator.Provider, req.StatementDescriptor, req.StatementDescriptorSuffix); err != nil {
+		return nil, simulator.Invalid(err.Error())
+	}
+	card, err := g.chargeCard(ctx, req.PaymentMethodID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	id := simulator.NewID("ch")
+	charge := &simulatedCharge{
+		Charge: Charge{
+			ID:              id,
+			Amount:          req.Amount,
+			Currency:        strings.ToLower(req.Currency),
+			CustomerID:      req.CustomerID,
+			PaymentMethodID: req.PaymentMethodID,
+			Description:     req.Description,
+			Metadata:        req.Metadata,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+			ProviderID:      id,
+			Provider:        simulator.Provider,
+
+			StatementDescriptor: simulatedDescriptor(req),
+			ReceiptEmail:        req.ReceiptEmail,
+		},
+		Card:    card,
+		Capture: req.Capture,
+	}
+
+	switch card.Outcome {
+	case simulator.OutcomeDeclined:
+		charge.Status = "failed"
+		if err := g.saveCharge(ctx, charge); err != nil {
+			return nil, err
+		}
+		return nil, simulator.Decline(card)
+	case simulator.OutcomeActionRequired:
+		charge.Status = "requires_action"
+		if err := g.saveCharge(ctx, charge); err != nil {
+			return nil, err
+		}
+		return &charge.Charge, nil
+	}
+
+	if err := g.authorize(ctx, charge); err != nil {
+		return nil, err
+	}
+	return &charge.Charge, nil
+}
+
+// AuthenticateCharge completes the 3D Secure authentication of a charge
+// made with a card requiring it, as the cardholder would. A failed
+// authentication fails the charge.
+func (g *SimulatorGateway) AuthenticateCharge(ctx context.Context, chargeID string, succeed bool) (*Charge, error) {
+	charge, err := g.loadCharge(ctx, chargeID)
+	if err != nil {
+		return nil, err
+	}
+	if charge.Status != "requires_action" {
+		return nil, simulator.Invalid("charge does not require authentication")
+	}
+
+	if !succeed {
+		charge.Status = "failed"
+		charge.UpdatedAt = time.Now().UTC()
+		if err := g.saveCharge(ctx, charge); err != nil {
+			return nil, err
+		}
+		return &charge.Charge, nil
+	}
+
+	if err := g.authorize(ctx, charge); err != nil {
+		return nil, err
+	}
+	return &charge.Charge, nil
+}
+
+func (g *SimulatorGateway) GetCharge(ctx context.Context, chargeID string) (*Charge, error) {
+	charge, err := g.loadCharge(ctx, chargeID)
+	if err != nil {
+		return nil, err
+	}
+	return &charge.Charge, nil
+}
+
+func (g *SimulatorGateway) UpdateCharge(ctx context.Context, chargeID string, req UpdateChargeRequest) (*Charge, error) {
+	charge, err := g.loadCharge(ctx, chargeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Description != "" {
+		charge.Description = req.Description
+	}
+	if req.Metadata != nil {
+		charge.Metadata = mergeSimulatorMetadata(charge.Metadata, req.Metadata)
+	}
+	charge.UpdatedAt = time.Now().UTC()
+
+	if err := g.saveCharge(ctx, charge); err != nil {
+		return nil, err
+	}
+	return &charge.Charge, nil
+}
+
+// CaptureCharge captures an authorization, in full or for less. Simulated
+// authorizations are captured once.
+func (g *SimulatorGateway) CaptureCharge(ctx context.Context, chargeID string, req CaptureChargeRequest) (*Charge, error) {
+	if req.KeepOpen {
+		return nil, simulator.Invalid("simulator charges are captured once")
+	}
+	charge, err := g.loadCharge(ctx, chargeID)
+	if err != nil {
+		return nil, err
+	}
+	if charge.Status != "requires_capture" {
+		return nil, simulator.Invalid("charge is not awaiting capture")
+	}
+	if charge.CaptureBefore != nil && time.Now().After(*charge.CaptureBefore) {
+		return nil, simulator.Invalid("authorization has expired")
+	}
+
+	amount := charge.Amount
+	if req.Amount > 0 {
+		if req.Amount > charge.Amount {
+			return nil, simulator.Invalid("capture amount exceeds the authorized amount")
+		}
+		amount = req.Amount
+	}
+
+	charge.Status = "succeeded"
+	charge.AmountCaptured = amount
+	charge.CaptureBefore = nil
+	charge.UpdatedAt = time.Now().UTC()
+	if err := g.saveCharge(ctx, charge); err != nil {
+		return nil, err
+	}
+	if err := g.openDispute(ctx, charge); err != nil {
+		return nil, err
+	}
+	return &charge.Charge, nil
+}
+
+func (g *SimulatorGateway) VoidCharge(ctx context.Context, chargeID string) (*Charge, error) {
+	charge, err := g.loadCharge(ctx, chargeID)
+	if err != nil {
+		return nil, err
+	}
+	if charge.Status != "requires_capture" && charge.Status != "requires_action" {
+		return nil, simulator.Invalid("only uncaptured charges can be voided")
+	}
+
+	charge.Status = "canceled"
+	charge.CaptureBefore = nil
+	charge.UpdatedAt = time.Now().UTC()
+	if err := g.saveCharge(ctx, charge); err != nil {
+		return nil, err
+	}
+	return &charge.Charge, nil
+}
+
+// ListCharges lists charges, newest first. Pages filtered by status may
+// hold fewer charges than the limit.
+func (g *SimulatorGateway) ListCharges(ctx context.Context, req ListChargesRequest) (*ChargeList, error) {
+	if err := g.simulator.Delay(ctx); err != nil {
+		return nil, err
+	}
+
+	records, nextCursor, err := simulator.List[*simulatedCharge](ctx, g.simulator, simulatorCharge, req.CustomerID, req.Cursor, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	charges := make([]*Charge, 0, len(records))
+	for _, record := range records {
+		if req.Status == "" || record.Status == req.Status {
+			charges = append(charges, &record.Charge)
+		}
+	}
+	return &ChargeList{
+		Charges:    charges,
+		Total:      len(charges),
+		HasMore:    nextCursor != "",
+		NextCursor: nextCursor,
+	}, nil
+}
+
+// CreateRefund refunds a captured charge, in full or for what remains
+func (g *SimulatorGateway) CreateRefund(ctx context.Context, req CreateRefundRequest) (*Refund, error) {
+	charge, err := g.loadCharge(ctx, req.ChargeID)
+	if err != nil {
+		return nil, err
+	}
+	if charge.Status != "succeeded" {
+		return nil, simulator.Invalid("only captured charges can be refunded")
+	}
+
+	remaining := charge.AmountCaptured - charge.AmountRefunded
+	amount := remaining
+	if req.Amount > 0 {
+		amount = req.Amount
+	}
+	if amount <= 0 || amount > remaining {
+		return nil, simulator.Invalid("refund amount exceeds what remains of the charge")
+	}
+
+	now := time.Now().UTC()
+	id := simulator.NewID("re")
+	refund := &Refund{
+		ID:         id,
+		ChargeID:   charge.ID,
+		Amount:     amount,
+		Currency:   charge.Currency,
+		Reason:     req.Reason,
+		Status:     "succeeded",
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		ProviderID: id,
+		Provider:   simulator.Provider,
+	}
+	if err := g.simulator.Save(ctx, simulatorRefund, id, charge.ID, refund); err != nil {
+		return nil, err
+	}
+
+	charge.AmountRefunded += amount
+	charge.UpdatedAt = now
+	if err := g.saveCharge(ctx, charge); err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+func (g *SimulatorGateway) GetRefund(ctx context.Context, refundID string) (*Refund, error) {
+	if err := g.simulator.Delay(ctx); err != nil {
+		return nil, err
+	}
+
+	var refund Refund
+	if err := g.simulator.Load(ctx, simulatorRefund, refundID, &refund); err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+func (g *SimulatorGateway) UpdateRefund(ctx context.Context, refundID string, req UpdateRefundRequest) (*Refund, error) {
+	refund, err := g.GetRefund(ctx, refundID)
+	if err != nil {
+		return nil, err
+	}
+
+	refund.Metadata = mergeSimulatorMetadata(refund.Metadata, req.Metadata)
+	refund.UpdatedAt = time.Now().UTC()
+	if err := g.simulator.Save(ctx, simulatorRefund, refund.ID, refund.ChargeID, refund); err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+func (g *SimulatorGateway) ListRefunds(ctx context.Context, req ListRefundsRequest) (*RefundList, error) {
+	if err := g.simulator.Delay(ctx); err != nil {
+		return nil, err
+	}
+
+	refunds, nextCursor, err := simulator.List[*Refund](ctx, g.simulator, simulatorRefund, req.ChargeID, req.Cursor, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+	return &RefundList{
+		Refunds:    refunds,
+		Total:      len(refunds),
+		HasMore:    nextCursor != "",
+		NextCursor: nextCursor,
+	}, nil
+}
+
+// CreateSubscription starts a monthly subscription to any plan ID
+func (g *SimulatorGateway) CreateSubscription(ctx context.Context, req CreateSubscriptionRequest) (*Subscription, error) {
+	if req.PlanID == "" {
+		return nil, simulator.Invalid("plan_id is required")
+	}
+	if _, err := g.GetCustomer(ctx, req.CustomerID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	id := simulator.NewID("sub")
+	subscription := &Subscription{
+		ID:                 id,
+		CustomerID:         req.CustomerID,
+		PlanID:             req.PlanID,
+		Status:             "active",
+		CurrentPeriodStart: now,
+		CurrentPeriodEnd:   now.AddDate(0, 1, 0),
+		Metadata:           req.Metadata,
+		CreatedAt:          now,
+		UpdatedAt:          now,
+		ProviderID:         id,
+		Provider:           simulator.Provider,
+	}
+	if err := g.simulator.Save(ctx, simulatorSubscription, id, req.CustomerID, subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+func (g *SimulatorGateway) GetSubscription(ctx context.Context, subscriptionID string) (*Subscription, error) {
+	if err := g.simulator.Delay(ctx); err != nil {
+		return nil, err
+	}
+
+	var subscription Subscription
+	if err := g.simulator.Load(ctx, simulatorSubscription, subscriptionID, &subscription); err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+func (g *SimulatorGateway) UpdateSubscription(ctx context.Context, subscriptionID string, req UpdateSubscriptionRequest) (*Subscription, error) {
+	subscription, err := g.GetSubscription(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if subscription.Status == "canceled" {
+		return nil, simulator.Invalid("canceled subscriptions cannot be updated")
+	}
+
+	if req.PlanID != "" {
+		subscription.PlanID = req.PlanID
+	}
+	if req.Metadata != nil {
+		subscription.Metadata = mergeSimulatorMetadata(subscription.Metadata, req.Metadata)
+	}
+	subscription.UpdatedAt = time.Now().UTC()
+
+	if err := g.simulator.Save(ctx, simulatorSubscription, subscription.ID, subscription.CustomerID, subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// CancelSubscription cancels a subscription now, or marks it with
+// cancel_at_period_end metadata to end with its period
+func (g *SimulatorGateway) CancelSubscription(ctx context.Context, subscriptionID string, req CancelSubscriptionRequest) (*Subscription, error) {
+	subscription, err := g.GetSubscription(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if subscription.Status == "canceled" {
+		return nil, simulator.Invalid("subscription is already canceled")
+	}
+
+	if req.AtPeriodEnd {
+		subscription.Metadata = mergeSimulatorMetadata(subscription.Metadata, map[string]interface{}{"cancel_at_period_end": true})
+	} else {
+		subscription.Status = "canceled"
+	}
+	subscription.UpdatedAt = time.Now().UTC()
+
+	if err := g.simulator.Save(ctx, simulatorSubscription, subscription.ID, subscription.CustomerID, subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+func (g *SimulatorGateway) ListSubscriptions(ctx context.Context, req ListSubscriptionsRequest) (*SubscriptionList, error) {
+	if err := g.simulator.Delay(ctx); err != nil {
+		return nil, err
+	}
+
+	records, nextCursor, err := simulator.List[*Subscription](ctx, g.simulator, simulatorSubscription, req.CustomerID, req.Cursor, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	subscriptions := make([]*Subscription, 0, len(records))
+	for _, subscription := range records {
+		if req.Status == "" || subscription.Status == req.Status {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	return &SubscriptionList{
+		Subscriptions: subscriptions,
+		Total:         len(subscriptions),
+		HasMore:       nextCursor != "",
+		NextCursor:    nextCursor,
+	}, nil
+}
+
+func (g *SimulatorGateway) GetDispute(ctx context.Context, disputeID string) (*Dispute, error) {
+	if err := g.simulator.Delay(ctx); err != nil {
+		return nil, err
+	}
+
+	var dispute Dispute
+	if err := g.simulator.Load(ctx, simulatorDispute, disputeID, &dispute); err != nil {
+		return nil, err
+	}
+	return &dispute, nil
+}
+
+func (g *SimulatorGateway) ListDisputes(ctx context.Context, req ListDisputesRequest) (*DisputeList, error) {
+	if err := g.simulator.Delay(ctx); err != nil {
+		return nil, err
+	}
+
+	records, nextCursor, err := simulator.List[*Dispute](ctx, g.simulator, simulatorDispute, req.ChargeID, req.Cursor, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	disputes := make([]*Dispute, 0, len(records))
+	for _, dispute := range records {
+		if req.Status == "" || dispute.Status == req.Status {
+			disputes = append(disputes, dispute)
+		}
+	}
+	return &DisputeList{
+		Disputes:   disputes,
+		Total:      len(disputes),
+		HasMore:    nextCursor != "",
+		NextCursor: nextCursor,
+	}, nil
+}
+
+// AcceptDispute concedes a dispute, which loses it; inquiries close instead
+func (g *SimulatorGateway) AcceptDispute(ctx context.Context, disputeID string) (*Dispute, error) {
+	dispute, err := g.openDisputeByID(ctx, disputeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.HasPrefix(dispute.Status, "warning_") {
+		dispute.Status = "warning_closed"
+	} else {
+		dispute.Status = "lost"
+	}
+	return g.saveDispute(ctx, dispute)
+}
+
+// SubmitDisputeEvidence records evidence on a dispute. Submitted evidence
+// decides the dispute at once: text containing winning_evidence wins it,
+// losing_evidence loses it, and anything else leaves it under review.
+// Inquiries close when evidence is submitted.
+func (g *SimulatorGateway) SubmitDisputeEvidence(ctx context.Context, disputeID string, req SubmitDisputeEvidenceRequest) (*Dispute, error) {
+	dispute, err := g.openDisputeByID(ctx, disputeID)
+	if err != nil {
+		return nil, err
+	}
+	if !req.Submit {
+		return g.saveDispute(ctx, dispute)
+	}
+
+	if strings.HasPrefix(dispute.Status, "warning_") {
+		dispute.Status = "warning_closed"
+		return g.saveDispute(ctx, dispute)
+	}
+
+	dispute.Status = "under_review"
+	for _, evidence := range req.Evidence {
+		switch {
+		case strings.Contains(evidence.Text, "winning_evidence"):
+			dispute.Status = "won"
+		case strings.Contains(evidence.Text, "losing_evidence"):
+			dispute.Status = "lost"
+		}
+	}
+	return g.saveDispute(ctx, dispute)
+}
+
+// chargeCard returns the test card a charge is made with: a saved simulator
+// card, or a test card number
+func (g *SimulatorGateway) chargeCard(ctx context.Context, paymentMethodID string) (simulator.Card, error) {
+	if err := g.simulator.Delay(ctx); err != nil {
+		return simulator.Card{}, err
+	}
+	if paymentMethodID == "" {
+		return simulator.Card{}, simulator.Invalid("payment_method_id is required")
+	}
+	if !strings.HasPrefix(paymentMethodID, "pm_") {
+		return simulator.Lookup(paymentMethodID)
+	}
+
+	var method simulatedPaymentMethod
+	if err := g.simulator.Load(ctx, simulatorPaymentMethod, paymentMethodID, &method); err != nil {
+		return simulator.Card{}, err
+	}
+	return simulator.Lookup(method.Number)
+}
+
+// authorize approves a charge, capturing it if the request asked to and
+// opening the dispute its card triggers once it is captured
+func (g *SimulatorGateway) authorize(ctx context.Context, charge *simulatedCharge) error {
+	now := time.Now().UTC()
+	charge.UpdatedAt = now
+	if charge.Capture {
+		charge.Status = "succeeded"
+		charge.AmountCaptured = charge.Amount
+	} else {
+		charge.Status = "requires_capture"
+		captureBefore := now.Add(simulatorAuthorizationWindow)
+		charge.CaptureBefore = &captureBefore
+	}
+
+	if err := g.saveCharge(ctx, charge); err != nil {
+		return err
+	}
+	if charge.Capture {
+		return g.openDispute(ctx, charge)
+	}
+	return nil
+}
+
+// openDispute opens the dispute or inquiry a captured charge's card
+// triggers, if any
+func (g *SimulatorGateway) openDispute(ctx context.Context, charge *simulatedCharge) error {
+	status, reason := "", ""
+	switch charge.Card.Outcome {
+	case simulator.OutcomeDisputed:
+		status, reason = "needs_response", "fraudulent"
+	case simulator.OutcomeInquiry:
+		status, reason = "warning_needs_response", "general"
+	default:
+		return nil
+	}
+
+	now := time.Now().UTC()
+	id := simulator.NewID("dp")
+	dispute := &Dispute{
+		ID:            id,
+		ChargeID:      charge.ID,
+		Amount:        charge.AmountCaptured,
+		Currency:      charge.Currency,
+		Reason:        reason,
+		Status:        status,
+		EvidenceDueBy: now.Add(simulatorEvidenceWindow),
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		ProviderID:    id,
+		Provider:      simulator.Provider,
+	}
+	return g.simulator.Save(ctx, simulatorDispute, id, charge.ID, dispute)
+}
+
+// openDisputeByID returns a dispute that can still be answered
+func (g *SimulatorGateway) openDisputeByID(ctx context.Context, disputeID string) (*Dispute, error) {
+	dispute, err := g.GetDispute(ctx, disputeID)
+	if err != nil {
+		return nil, err
+	}
+	if dispute.Status != "needs_response" && dispute.Status != "warning_needs_response" {
+		return nil, simulator.Invalid("dispute can no longer be answered")
+	}
+	return dispute, nil
+}
+
+func (g *SimulatorGateway) saveDispute(ctx context.Context, dispute *Dispute) (*Dispute, error) {
+	dispute.UpdatedAt = time.Now().UTC()
+	if err := g.simulator.Save(ctx, simulatorDispute, dispute.ID, dispute.ChargeID, dispute); err != nil {
+		return nil, err
+	}
+	return dispute, nil
+}
+
+func (g *SimulatorGateway) loadCharge(ctx context.Context, chargeID string) (*simulatedCharge, error) {
+	if err := g.simulator.Delay(ctx); err != nil {
+		return nil, err
+	}
+
+	var charge simulatedCharge
+	if err := g.simulator.Load(ctx, simulatorCharge, chargeID, &charge); err != nil {
+		return nil, err
+	}
+	return &charge, nil
+}
+
+// saveCharge stores a charge under its customer, so charges list by customer
+func (g *SimulatorGateway) saveCharge(ctx context.Context, charge *simulatedCharge) error {
+	return g.simulator.Save(ctx, simulatorCharge, charge.ID, charge.CustomerID, charge)
+}
+
+// simulatorFingerprint derives a stable fingerprint from a card number, so
+// the same test card saved twice shares it
+func simulatorFingerprint(number string) string {
+	sum := sha256.Sum256([]byte("simulator:" + number))
+	return hex.EncodeToString(sum[:8])
+}
+
+// simulatedDescriptor is what a simulated charge's statement shows: its own
+// descriptor, or its suffix after a shortened account descriptor as Stripe
+// prints it
+func simulatedDescriptor(req CreateChargeRequest) string {
+	if req.StatementDescriptor != "" {
+		return req.StatementDescriptor
+	}
+	if req.StatementDescriptorSuffix != "" {
+		return "SIMULATOR* " + req.StatementDescriptorSuffix
+	}
+	return ""
+}
+
+// mergeSimulatorMetadata sets metadata keys, removing those set to empty
+// strings, as Stripe does
+func mergeSimulatorMetadata(metadata, updates map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(metadata)+len(updates))
+	for key, value := range metadata {
+		merged[key] = value
+	}
+	for key, value := range updates {
+		if s, ok := value.(string); ok && s == "" {
+			delete(merged, key)
+			continue
+		}
+		merged[key] = value
+	}
+	return merged
+}
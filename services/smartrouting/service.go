@@ -0,0 +1,428 @@
+package smartrouting
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"apis/payments/services"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// rateKey identifies an authorization rate cell; empty fields are aggregated
+type rateKey struct {
+	provider   string
+	currency   string
+	binCountry string
+}
+
+// Service ranks the providers a gateway charge can be sent to, cheapest or
+// most likely to be authorized first, and applies the rule overrides that
+// administrators maintain. Authorization rates and rules are cached for the
+// refresh interval; rules are reloaded at once when changed here.
+type Service struct {
+	store  Store
+	stats  Stats
+	config *Config
+	tracer trace.Tracer
+
+	mu          sync.Mutex
+	rules       []*Rule
+	rulesLoaded time.Time
+	rates       map[rateKey]*AuthRate
+	ratesLoaded time.Time
+}
+
+// NewService creates a new smart routing service. Stats may be nil, in which
+// case no authorization rates are known and charges are ranked by cost.
+func NewService(store Store, stats Stats, config *Config) *Service {
+	if config == nil {
+		config = LoadConfig()
+	}
+
+	return &Service{
+		store:  store,
+		stats:  stats,
+		config: config,
+		tracer: otel.Tracer("payments.smartrouting"),
+	}
+}
+
+// Providers returns the providers charges are routed among when no candidates
+// are given: those with configured fees
+func (s *Service) Providers() []string {
+	return sortedProviders(s.config.Fees)
+}
+
+// Decide ranks the candidate providers for a charge, or every provider with
+// configured fees when none are given. The first enabled rule matching the
+// charge may switch the strategy, limit the candidates or pin a provider;
+// given candidates are only ever reordered or narrowed, never added to.
+func (s *Service) Decide(ctx context.Context, charge Charge, candidates []string) (*Decision, error) {
+	ctx, span := s.tracer.Start(ctx, "Decide")
+	defer span.End()
+
+	open := len(candidates) == 0
+	if open {
+		candidates = s.Providers()
+	}
+	decision := &Decision{
+		Charge:   charge,
+		Strategy: s.config.Strategy,
+	}
+
+	rules, err := s.cachedRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, rule := range rules {
+		if !rule.Enabled || !rule.Matches(charge) {
+			continue
+		}
+		decision.RuleID = rule.ID
+		if rule.Strategy != "" {
+			decision.Strategy = rule.Strategy
+		}
+		if len(rule.Providers) > 0 {
+			if open {
+				candidates = rule.Providers
+			} else if limited := intersect(candidates, rule.Providers); len(limited) > 0 {
+				candidates = limited
+			}
+		}
+		if rule.PinnedProvider != "" && (open || contains(candidates, rule.PinnedProvider)) {
+			decision.Pinned = rule.PinnedProvider
+		}
+		break
+	}
+
+	rates := s.cachedRates(ctx)
+	decision.Scores = make([]Score, 0, len(candidates))
+	for _, provider := range candidates {
+		decision.Scores = append(decision.Scores, s.score(provider, charge, rates))
+	}
+	rank(decision.Scores, decision.Strategy)
+
+	if decision.Pinned != "" {
+		decision.Providers = append(decision.Providers, decision.Pinned)
+	}
+	for _, score := range decision.Scores {
+		if score.Provider != decision.Pinned {
+			decision.Providers = append(decision.Providers, score.Provider)
+		}
+	}
+
+	return decision, nil
+}
+
+// Rank orders the providers a gateway charge is tried at
+func (s *Service) Rank(ctx context.Context, req services.CreateChargeRequest, candidates []string) ([]string, error) {
+	decision, err := s.Decide(ctx, Charge{Amount: req.Amount, Currency: req.Currency, BINCountry: req.Country}, candidates)
+	if err != nil {
+		return nil, err
+	}
+	return decision.Providers, nil
+}
+
+// RecordAttempt records whether a provider authorized a charge so later
+// decisions learn from it. Failures to record are logged, never returned to
+// the charge.
+func (s *Service) RecordAttempt(ctx context.Context, provider string, req services.CreateChargeRequest, authorized bool) {
+	if s.stats == nil {
+		return
+	}
+
+	attempt := &Attempt{
+		Provider:    strings.ToLower(provider),
+		Currency:    strings.ToLower(req.Currency),
+		BINCountry:  strings.ToLower(req.Country),
+		Amount:      req.Amount,
+		Authorized:  authorized,
+		AttemptedAt: time.Now().UTC(),
+	}
+	if err := s.stats.RecordChargeAttempt(ctx, attempt); err != nil {
+		log.Printf("Failed to record charge attempt at %s: %v", provider, err)
+	}
+}
+
+// AuthRates returns the authorization rates decisions are currently made on
+func (s *Service) AuthRates(ctx context.Context) []*AuthRate {
+	rates := s.cachedRates(ctx)
+
+	cells := make([]*AuthRate, 0, len(rates))
+	for key, rate := range rates {
+		if key.currency != "" && key.binCountry != "" {
+			cells = append(cells, rate)
+		}
+	}
+	sort.Slice(cells, func(i, j int) bool {
+		if cells[i].Provider != cells[j].Provider {
+			return cells[i].Provider < cells[j].Provider
+		}
+		if cells[i].Currency != cells[j].Currency {
+			return cells[i].Currency < cells[j].Currency
+		}
+		return cells[i].BINCountry < cells[j].BINCountry
+	})
+	return cells
+}
+
+// ListRules returns every rule in the order they are matched
+func (s *Service) ListRules(ctx context.Context) ([]*Rule, error) {
+	ctx, span := s.tracer.Start(ctx, "ListRules")
+	defer span.End()
+
+	return s.store.ListSmartRoutingRules(ctx)
+}
+
+// GetRule returns a rule
+func (s *Service) GetRule(ctx context.Context, id string) (*Rule, error) {
+	ctx, span := s.tracer.Start(ctx, "GetRule")
+	defer span.End()
+
+	rule, err := s.store.GetSmartRoutingRule(ctx, id)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return rule, nil
+}
+
+// CreateRule adds a rule override
+func (s *Service) CreateRule(ctx context.Context, rule *Rule) (*Rule, error) {
+	ctx, span := s.tracer.Start(ctx, "CreateRule")
+	defer span.End()
+
+	rule.Normalize()
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+
+	rule.ID = fmt.Sprintf("rrl_%s", uuid.New().String())
+	created, err := s.store.CreateSmartRoutingRule(ctx, rule)
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidateRules()
+	log.Printf("Smart routing rule %s created by %s", created.ID, created.UpdatedBy)
+	return created, nil
+}
+
+// UpdateRule replaces a rule override
+func (s *Service) UpdateRule(ctx context.Context, rule *Rule) (*Rule, error) {
+	ctx, span := s.tracer.Start(ctx, "UpdateRule")
+	defer span.End()
+
+	rule.Normalize()
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.store.UpdateSmartRoutingRule(ctx, rule)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	s.invalidateRules()
+	log.Printf("Smart routing rule %s updated by %s", updated.ID, updated.UpdatedBy)
+	return updated, nil
+}
+
+// DeleteRule removes a rule override
+func (s *Service) DeleteRule(ctx context.Context, id string) error {
+	ctx, span := s.tracer.Start(ctx, "DeleteRule")
+	defer span.End()
+
+	deleted, err := s.store.DeleteSmartRoutingRule(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrRuleNotFound
+	}
+
+	s.invalidateRules()
+	return nil
+}
+
+// score prices a charge at a provider and looks up its authorization rate,
+// falling back from the charge's currency and BIN country to the currency
+// alone, then to the provider alone, until a rate has enough attempts
+func (s *Service) score(provider string, charge Charge, rates map[rateKey]*AuthRate) Score {
+	score := Score{Provider: provider, RateScope: ScopeNone}
+
+	if fee, ok := s.config.Fees[provider]; ok {
+		home := s.config.HomeCountries[provider]
+		crossBorder := home != "" && charge.BINCountry != "" && !strings.EqualFold(home, charge.BINCountry)
+		score.Fee = fee.Cost(charge.Amount, crossBorder)
+		score.Priced = true
+	}
+
+	currency := strings.ToLower(charge.Currency)
+	country := strings.ToLower(charge.BINCountry)
+	for _, cell := range []struct {
+		key   rateKey
+		scope string
+	}{
+		{rateKey{provider, currency, country}, ScopeCountry},
+		{rateKey{provider, currency, ""}, ScopeCurrency},
+		{rateKey{provider, "", ""}, ScopeProvider},
+	} {
+		rate, ok := rates[cell.key]
+		if ok && rate.Attempts >= s.config.MinSamples {
+			score.AuthRate = rate.Rate()
+			score.Attempts = rate.Attempts
+			score.RateScope = cell.scope
+			break
+		}
+	}
+
+	return score
+}
+
+// rank sorts scores by the strategy. The cost strategy puts unpriced
+// providers last and breaks ties on authorization rate; the auth_rate
+// strategy puts providers without a trusted rate last and breaks ties on
+// cost. Remaining ties keep the providers in name order.
+func rank(scores []Score, strategy string) {
+	byCost := func(a, b Score) (bool, bool) {
+		if a.Priced != b.Priced {
+			return a.Priced, true
+		}
+		if a.Fee != b.Fee {
+			return a.Fee < b.Fee, true
+		}
+		return false, false
+	}
+	byRate := func(a, b Score) (bool, bool) {
+		aKnown, bKnown := a.RateScope != ScopeNone, b.RateScope != ScopeNone
+		if aKnown != bKnown {
+			return aKnown, true
+		}
+		if a.AuthRate != b.AuthRate {
+			return a.AuthRate > b.AuthRate, true
+		}
+		return false, false
+	}
+
+	order := []func(a, b Score) (bool, bool){byCost, byRate}
+	if strategy == StrategyAuthRate {
+		order = []func(a, b Score) (bool, bool){byRate, byCost}
+	}
+
+	sort.SliceStable(scores, func(i, j int) bool {
+		for _, compare := range order {
+			if less, decided := compare(scores[i], scores[j]); decided {
+				return less
+			}
+		}
+		return scores[i].Provider < scores[j].Provider
+	})
+}
+
+// cachedRules returns the rules, reloading them once the refresh interval
+// has passed
+func (s *Service) cachedRules(ctx context.Context) ([]*Rule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.rules != nil && time.Since(s.rulesLoaded) < s.config.RefreshInterval {
+		return s.rules, nil
+	}
+
+	rules, err := s.store.ListSmartRoutingRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load routing rules: %w", err)
+	}
+	if rules == nil {
+		rules = []*Rule{}
+	}
+	s.rules = rules
+	s.rulesLoaded = time.Now()
+	return rules, nil
+}
+
+// invalidateRules makes the next decision reload the rules
+func (s *Service) invalidateRules() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rules = nil
+}
+
+// cachedRates returns authorization rates by cell, reloading them once the
+// refresh interval has passed. When they cannot be reloaded the previous
+// rates are kept, so decisions degrade to cost rather than fail.
+func (s *Service) cachedRates(ctx context.Context) map[rateKey]*AuthRate {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stats == nil || (s.rates != nil && time.Since(s.ratesLoaded) < s.config.RefreshInterval) {
+		return s.rates
+	}
+
+	s.ratesLoaded = time.Now()
+	rows, err := s.stats.AuthorizationRates(ctx, time.Now().Add(-s.config.Window))
+	if err != nil {
+		log.Printf("Warning: failed to load authorization rates, keeping previous rates: %v", err)
+		return s.rates
+	}
+
+	rates := make(map[rateKey]*AuthRate)
+	add := func(key rateKey, row *AuthRate) {
+		rate, ok := rates[key]
+		if !ok {
+			rate = &AuthRate{Provider: key.provider, Currency: key.currency, BINCountry: key.binCountry}
+			rates[key] = rate
+		}
+		rate.Attempts += row.Attempts
+		rate.Authorized += row.Authorized
+	}
+	for _, row := range rows {
+		provider := strings.ToLower(row.Provider)
+		currency := strings.ToLower(row.Currency)
+		add(rateKey{provider, currency, strings.ToLower(row.BINCountry)}, row)
+		add(rateKey{provider, currency, ""}, row)
+		add(rateKey{provider, "", ""}, row)
+	}
+	s.rates = rates
+	return rates
+}
+
+// notFound maps a missing rule to ErrRuleNotFound
+func notFound(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrRuleNotFound
+	}
+	return err
+}
+
+// intersect returns the candidates also in the allowed list, keeping the
+// candidates' order
+func intersect(candidates, allowed []string) []string {
+	var kept []string
+	for _, candidate := range candidates {
+		if contains(allowed, candidate) {
+			kept = append(kept, candidate)
+		}
+	}
+	return kept
+}
+
+// contains reports whether a provider is in a list
+func contains(providers []string, provider string) bool {
+	for _, p := range providers {
+		if p == provider {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,294 @@
+package smartrouting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"apis/payments/services/config"
+)
+
+// Strategies for ranking the providers a charge can be sent to
+const (
+	StrategyCost     = "cost"      // Cheapest provider first
+	StrategyAuthRate = "auth_rate" // Highest historical authorization rate first
+)
+
+// Scopes an authorization rate was measured over, from most to least specific
+const (
+	ScopeCountry  = "country"  // Provider, currency and card BIN country
+	ScopeCurrency = "currency" // Provider and currency
+	ScopeProvider = "provider" // Provider alone
+	ScopeNone     = "none"     // Too few attempts anywhere to trust a rate
+)
+
+// Errors returned by the smart routing service
+var (
+	ErrRuleNotFound = errors.New("routing rule not found")
+	ErrInvalidRule  = errors.New("invalid routing rule")
+)
+
+// Fee is a provider's processing price: a percentage in basis points plus a
+// fixed amount in the charge currency's minor units, and a surcharge in basis
+// points for cards issued outside the provider's home country
+type Fee struct {
+	PercentBps     int64 `json:"percent_bps"`
+	Fixed          int64 `json:"fixed"`
+	CrossBorderBps int64 `json:"cross_border_bps"`
+}
+
+// Cost returns the fee for a charge in the currency's minor units
+func (f Fee) Cost(amount int64, crossBorder bool) int64 {
+	bps := f.PercentBps
+	if crossBorder {
+		bps += f.CrossBorderBps
+	}
+	return amount*bps/10000 + f.Fixed
+}
+
+// Config holds the default strategy, provider pricing and how authorization
+// rates are measured
+type Config struct {
+	Enabled         bool
+	Strategy        string
+	Fees            map[string]Fee    // Provider to its pricing; these are the providers charges are routed among
+	HomeCountries   map[string]string // Provider to the country its cards are domestic in
+	MinSamples      int64             // Attempts a rate needs before it is trusted
+	Window          time.Duration     // How far back authorization rates look
+	RefreshInterval time.Duration     // How long rates and rules are cached
+}
+
+// LoadConfig loads the smart routing configuration from environment
+// variables. Fees are comma-separated provider=percent_bps:fixed:cross_border_bps
+// entries, e.g. SMART_ROUTING_FEES=stripe=290:30:150,adyen=260:12:100; home
+// countries are provider=country pairs. Invalid fees are logged and ignored.
+func LoadConfig() *Config {
+	config := &Config{
+		Enabled:         os.Getenv("SMART_ROUTING_ENABLED") == "true",
+		Strategy:        StrategyCost,
+		Fees:            make(map[string]Fee),
+		HomeCountries:   make(map[string]string),
+		MinSamples:      int64(config.Int("SMART_ROUTING_MIN_SAMPLES", 50)),
+		Window:          time.Duration(config.Int("SMART_ROUTING_WINDOW_HOURS", 168)) * time.Hour,
+		RefreshInterval: time.Duration(config.Int("SMART_ROUTING_REFRESH_SECONDS", 300)) * time.Second,
+	}
+
+	if strategy := strings.ToLower(os.Getenv("SMART_ROUTING_STRATEGY")); strategy != "" {
+		if validStrategy(strategy) {
+			config.Strategy = strategy
+		} else {
+			log.Printf("Warning: ignoring unknown SMART_ROUTING_STRATEGY %q", strategy)
+		}
+	}
+	fees, err := ParseFees(os.Getenv("SMART_ROUTING_FEES"))
+	if err != nil {
+		log.Printf("Warning: ignoring SMART_ROUTING_FEES: %v", err)
+	}
+	for provider, fee := range fees {
+		config.Fees[provider] = fee
+	}
+	for _, pair := range strings.Split(os.Getenv("SMART_ROUTING_HOME_COUNTRIES"), ",") {
+		provider, country, ok := strings.Cut(pair, "=")
+		provider = strings.ToLower(strings.TrimSpace(provider))
+		country = strings.ToLower(strings.TrimSpace(country))
+		if ok && provider != "" && country != "" {
+			config.HomeCountries[provider] = country
+		}
+	}
+
+	return config
+}
+
+// ParseFees parses provider pricing such as "stripe=290:30:150,adyen=260:12",
+// the cross-border surcharge being optional
+func ParseFees(raw string) (map[string]Fee, error) {
+	fees := make(map[string]Fee)
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		provider, price, ok := strings.Cut(entry, "=")
+		provider = strings.ToLower(strings.TrimSpace(provider))
+		fields := strings.Split(price, ":")
+		if !ok || provider == "" || len(fields) < 2 || len(fields) > 3 {
+			return nil, fmt.Errorf("invalid fee %q: want \"provider=percent_bps:fixed[:cross_border_bps]\"", entry)
+		}
+
+		values := make([]int64, 3)
+		for i, field := range fields {
+			value, err := strconv.ParseInt(strings.TrimSpace(field), 10, 64)
+			if err != nil || value < 0 {
+				return nil, fmt.Errorf("invalid fee %q: %q must be a non-negative integer", entry, field)
+			}
+			values[i] = value
+		}
+		fees[provider] = Fee{PercentBps: values[0], Fixed: values[1], CrossBorderBps: values[2]}
+	}
+
+	return fees, nil
+}
+
+// Charge is what a routing decision is made on
+type Charge struct {
+	Amount     int64  `json:"amount"`
+	Currency   string `json:"currency"`
+	BINCountry string `json:"bin_country"`
+}
+
+// Rule overrides routing for charges matching a currency, card BIN country
+// and amount range. Empty criteria match anything and amount bounds of 0 are
+// open. A rule may switch the strategy, limit the providers considered, or
+// pin a provider that is tried before the ranked rest.
+type Rule struct {
+	ID             string    `json:"id"`
+	Currency       string    `json:"currency,omitempty"`
+	BINCountry     string    `json:"bin_country,omitempty"`
+	MinAmount      int64     `json:"min_amount,omitempty"`
+	MaxAmount      int64     `json:"max_amount,omitempty"`
+	Strategy       string    `json:"strategy,omitempty"`
+	Providers      []string  `json:"providers,omitempty"`
+	PinnedProvider string    `json:"pinned_provider,omitempty"`
+	Priority       int       `json:"priority"`
+	Enabled        bool      `json:"enabled"`
+	Description    string    `json:"description,omitempty"`
+	UpdatedBy      string    `json:"updated_by,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// Normalize lowercases the rule's criteria and providers
+func (r *Rule) Normalize() {
+	r.Currency = strings.ToLower(strings.TrimSpace(r.Currency))
+	r.BINCountry = strings.ToLower(strings.TrimSpace(r.BINCountry))
+	r.Strategy = strings.ToLower(strings.TrimSpace(r.Strategy))
+	r.PinnedProvider = strings.ToLower(strings.TrimSpace(r.PinnedProvider))
+	providers := make([]string, 0, len(r.Providers))
+	for _, provider := range r.Providers {
+		if provider = strings.ToLower(strings.TrimSpace(provider)); provider != "" {
+			providers = append(providers, provider)
+		}
+	}
+	r.Providers = providers
+}
+
+// Validate checks a rule's criteria and that it overrides something
+func (r *Rule) Validate() error {
+	if r.Currency != "" && len(r.Currency) != 3 {
+		return fmt.Errorf("%w: currency must be a 3-letter ISO code", ErrInvalidRule)
+	}
+	if r.BINCountry != "" && len(r.BINCountry) != 2 {
+		return fmt.Errorf("%w: BIN country must be a 2-letter ISO code", ErrInvalidRule)
+	}
+	if r.MinAmount < 0 || r.MaxAmount < 0 || (r.MaxAmount > 0 && r.MaxAmount < r.MinAmount) {
+		return fmt.Errorf("%w: amount bounds must be non-negative and ordered", ErrInvalidRule)
+	}
+	if r.Strategy != "" && !validStrategy(r.Strategy) {
+		return fmt.Errorf("%w: strategy must be %s or %s", ErrInvalidRule, StrategyCost, StrategyAuthRate)
+	}
+	if r.Strategy == "" && len(r.Providers) == 0 && r.PinnedProvider == "" {
+		return fmt.Errorf("%w: a rule must set a strategy, providers or a pinned provider", ErrInvalidRule)
+	}
+	return nil
+}
+
+// Matches reports whether a charge falls under the rule
+func (r *Rule) Matches(charge Charge) bool {
+	if r.Currency != "" && r.Currency != strings.ToLower(charge.Currency) {
+		return false
+	}
+	if r.BINCountry != "" && r.BINCountry != strings.ToLower(charge.BINCountry) {
+		return false
+	}
+	if r.MinAmount > 0 && charge.Amount < r.MinAmount {
+		return false
+	}
+	if r.MaxAmount > 0 && charge.Amount > r.MaxAmount {
+		return false
+	}
+	return true
+}
+
+// AuthRate is how many charge attempts at a provider were authorized
+type AuthRate struct {
+	Provider   string `json:"provider"`
+	Currency   string `json:"currency"`
+	BINCountry string `json:"bin_country"`
+	Attempts   int64  `json:"attempts"`
+	Authorized int64  `json:"authorized"`
+}
+
+// Rate returns the share of attempts that were authorized
+func (a *AuthRate) Rate() float64 {
+	if a.Attempts == 0 {
+		return 0
+	}
+	return float64(a.Authorized) / float64(a.Attempts)
+}
+
+// Attempt is the outcome of one charge attempt at a provider
+type Attempt struct {
+	Provider    string
+	Currency    string
+	BINCountry  string
+	Amount      int64
+	Authorized  bool
+	AttemptedAt time.Time
+}
+
+// Score is how a provider ranked for a charge
+type Score struct {
+	Provider  string  `json:"provider"`
+	Fee       int64   `json:"fee"`
+	Priced    bool    `json:"priced"` // False when no fee is configured for the provider
+	AuthRate  float64 `json:"auth_rate"`
+	Attempts  int64   `json:"attempts"`
+	RateScope string  `json:"rate_scope"`
+}
+
+// Decision is the order providers are tried in for a charge and why
+type Decision struct {
+	Charge    Charge   `json:"charge"`
+	Strategy  string   `json:"strategy"`
+	RuleID    string   `json:"rule_id,omitempty"`
+	Pinned    string   `json:"pinned,omitempty"`
+	Providers []string `json:"providers"`
+	Scores    []Score  `json:"scores"`
+}
+
+// Store persists routing rule overrides
+type Store interface {
+	CreateSmartRoutingRule(ctx context.Context, rule *Rule) (*Rule, error)
+	GetSmartRoutingRule(ctx context.Context, id string) (*Rule, error)
+	ListSmartRoutingRules(ctx context.Context) ([]*Rule, error)
+	UpdateSmartRoutingRule(ctx context.Context, rule *Rule) (*Rule, error)
+	DeleteSmartRoutingRule(ctx context.Context, id string) (bool, error)
+}
+
+// Stats records charge attempts and reports authorization rates over them
+type Stats interface {
+	RecordChargeAttempt(ctx context.Context, attempt *Attempt) error
+	AuthorizationRates(ctx context.Context, since time.Time) ([]*AuthRate, error)
+}
+
+// validStrategy reports whether a strategy is known
+func validStrategy(strategy string) bool {
+	return strategy == StrategyCost || strategy == StrategyAuthRate
+}
+
+// sortedProviders returns the providers with configured fees, sorted
+func sortedProviders(fees map[string]Fee) []string {
+	providers := make([]string, 0, len(fees))
+	for provider := range fees {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+	return providers
+}
@@ -0,0 +1,1054 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"apis/payments/services/egress"
+	"apis/payments/services/receipts"
+
+	"github.com/google/uuid"
+)
+
+// Square API hosts and the API version requests are made against
+const (
+	squareProductionURL = "https://connect.squareup.com"
+	squareSandboxURL    = "https://connect.squareupsandbox.com"
+	squareAPIVersion    = "2024-01-18"
+)
+
+// squareListLimit is the most payments or refunds Square returns per page
+const squareListLimit = 100
+
+// SquareGateway implements the PaymentGateway and DisputeManager interfaces
+// for Square. Square has no free-form metadata on customers or payments, so
+// only a reference_id metadata key is carried through.
+type SquareGateway struct {
+	accessToken string
+	locationID  string
+	baseURL     string
+	client      *http.Client
+}
+
+// NewSquareGateway creates a new Square payment gateway instance. Payments
+// are taken at location_id, or the seller's main location when it is unset.
+func NewSquareGateway(config map[string]interface{}) (*SquareGateway, error) {
+	if err := validateSquareConfig(config); err != nil {
+		return nil, err
+	}
+
+	baseURL := squareSandboxURL
+	if config["environment"] == "production" {
+		baseURL = squareProductionURL
+	}
+	if override, ok := config["base_url"].(string); ok && override != "" {
+		baseURL = strings.TrimSuffix(override, "/")
+	}
+
+	egressConfig, err := egress.LoadConfig("square")
+	if err != nil {
+		return nil, &InvalidConfigError{Message: err.Error()}
+	}
+	transport, err := egress.NewTransport(egressConfig)
+	if err != nil {
+		return nil, &InvalidConfigError{Message: err.Error()}
+	}
+
+	locationID, _ := config["location_id"].(string)
+
+	return &SquareGateway{
+		accessToken: config["access_token"].(string),
+		locationID:  locationID,
+		baseURL:     baseURL,
+		client:      &http.Client{Transport: transport, Timeout: egressConfig.RequestTimeout},
+	}, nil
+}
+
+// GetProvider returns the provider name
+func (g *SquareGateway) GetProvider() string {
+	return "square"
+}
+
+// GetCapabilities returns the capabilities supported by Square. Square
+// charges in the seller's own currency only.
+func (g *SquareGateway) GetCapabilities() GatewayCapabilities {
+	return GatewayCapabilities{
+		SupportsCustomers:     true,
+		SupportsCharges:       true,
+		SupportsRefunds:       true,
+		SupportsSubscriptions: false,
+		SupportsDisputes:      true,
+		SupportsConnect:       false,
+		SupportsTax:           false,
+		MaxChargeAmount:       99999999,
+		MinChargeAmount:       100, // $1.00 in cents
+		SupportedCurrencies:   []string{"usd", "cad", "gbp", "eur", "aud", "jpy"},
+		SupportedCountries:    []string{"US", "CA", "GB", "IE", "ES", "FR", "AU", "JP"},
+		AuthorizationWindow:   7 * 24 * time.Hour, // card-not-present delayed capture
+	}
+}
+
+// Square API resources
+
+type squareMoney struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+type squareAddress struct {
+	AddressLine1                 string `json:"address_line_1,omitempty"`
+	AddressLine2                 string `json:"address_line_2,omitempty"`
+	Locality                     string `json:"locality,omitempty"`
+	AdministrativeDistrictLevel1 string `json:"administrative_district_level_1,omitempty"`
+	PostalCode                   string `json:"postal_code,omitempty"`
+	Country                      string `json:"country,omitempty"`
+}
+
+// squareCustomerParams are the customer fields that can be written
+type squareCustomerParams struct {
+	GivenName    string         `json:"given_name,omitempty"`
+	FamilyName   string         `json:"family_name,omitempty"`
+	EmailAddress string         `json:"email_address,omitempty"`
+	PhoneNumber  string         `json:"phone_number,omitempty"`
+	Address      *squareAddress `json:"address,omitempty"`
+	ReferenceID  string         `json:"reference_id,omitempty"`
+}
+
+type squareCustomer struct {
+	ID string `json:"id"`
+	squareCustomerParams
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type squareCard struct {
+	ID          string    `json:"id"`
+	CustomerID  string    `json:"customer_id"`
+	CardBrand   string    `json:"card_brand"`
+	Last4       string    `json:"last_4"`
+	ExpMonth    int       `json:"exp_month"`
+	ExpYear     int       `json:"exp_year"`
+	Fingerprint string    `json:"fingerprint"`
+	Enabled     bool      `json:"enabled"`
+	ReferenceID string    `json:"reference_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type squarePayment struct {
+	ID            string       `json:"id"`
+	Status        string       `json:"status"`
+	AmountMoney   squareMoney  `json:"amount_money"`
+	TotalMoney    squareMoney  `json:"total_money"`
+	RefundedMoney *squareMoney `json:"refunded_money"`
+	CustomerID    string       `json:"customer_id"`
+	ReferenceID   string       `json:"reference_id"`
+	Note          string       `json:"note"`
+	CardDetails   *struct {
+		Card struct {
+			ID string `json:"id"`
+		} `json:"card"`
+	} `json:"card_details"`
+	DelayedUntil *time.Time `json:"delayed_until"` // when an approved payment is canceled if not completed
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+
+	StatementDescriptionIdentifier string `json:"statement_description_identifier"`
+	BuyerEmailAddress              string `json:"buyer_email_address"`
+	ReceiptURL                     string `json:"receipt_url"`
+}
+
+type squareRefund struct {
+	ID          string      `json:"id"`
+	PaymentID   string      `json:"payment_id"`
+	Status      string      `json:"status"`
+	AmountMoney squareMoney `json:"amount_money"`
+	Reason      string      `json:"reason"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+type squareDispute struct {
+	ID              string      `json:"id"`
+	AmountMoney     squareMoney `json:"amount_money"`
+	Reason          string      `json:"reason"`
+	State           string      `json:"state"`
+	DueAt           string      `json:"due_at"`
+	DisputedPayment struct {
+		PaymentID string `json:"payment_id"`
+	} `json:"disputed_payment"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Customer management implementation
+
+func (g *SquareGateway) CreateCustomer(ctx context.Context, req CreateCustomerRequest) (*Customer, error) {
+	givenName, familyName := splitSquareName(req.Name)
+	body := struct {
+		IdempotencyKey string `json:"idempotency_key"`
+		squareCustomerParams
+	}{
+		IdempotencyKey: uuid.New().String(),
+		squareCustomerParams: squareCustomerParams{
+			GivenName:    givenName,
+			FamilyName:   familyName,
+			EmailAddress: req.Email,
+			PhoneNumber:  req.Phone,
+			Address:      toSquareAddress(req.Address),
+			ReferenceID:  squareReferenceID(req.Metadata),
+		},
+	}
+
+	var resp struct {
+		Customer squareCustomer `json:"customer"`
+	}
+	if err := g.do(ctx, http.MethodPost, "/v2/customers", nil, body, &resp); err != nil {
+		return nil, g.paymentError("customer_creation_failed", "failed to create customer", err)
+	}
+
+	return convertSquareCustomer(&resp.Customer), nil
+}
+
+func (g *SquareGateway) GetCustomer(ctx context.Context, customerID string) (*Customer, error) {
+	var resp struct {
+		Customer squareCustomer `json:"customer"`
+	}
+	if err := g.do(ctx, http.MethodGet, "/v2/customers/"+url.PathEscape(customerID), nil, nil, &resp); err != nil {
+		return nil, g.paymentError("customer_retrieval_failed", "failed to retrieve customer", err)
+	}
+
+	return convertSquareCustomer(&resp.Customer), nil
+}
+
+func (g *SquareGateway) UpdateCustomer(ctx context.Context, customerID string, req UpdateCustomerRequest) (*Customer, error) {
+	body := squareCustomerParams{
+		EmailAddress: req.Email,
+		PhoneNumber:  req.Phone,
+		Address:      toSquareAddress(req.Address),
+		ReferenceID:  squareReferenceID(req.Metadata),
+	}
+	if req.Name != "" {
+		body.GivenName, body.FamilyName = splitSquareName(req.Name)
+	}
+
+	var resp struct {
+		Customer squareCustomer `json:"customer"`
+	}
+	if err := g.do(ctx, http.MethodPut, "/v2/customers/"+url.PathEscape(customerID), nil, body, &resp); err != nil {
+		return nil, g.paymentError("customer_update_failed", "failed to update customer", err)
+	}
+
+	return convertSquareCustomer(&resp.Customer), nil
+}
+
+func (g *SquareGateway) DeleteCustomer(ctx context.Context, customerID string) error {
+	if err := g.do(ctx, http.MethodDelete, "/v2/customers/"+url.PathEscape(customerID), nil, nil, nil); err != nil {
+		return g.paymentError("customer_deletion_failed", "failed to delete customer", err)
+	}
+
+	return nil
+}
+
+// ListCustomers lists customers, searching by exact email when one is given
+func (g *SquareGateway) ListCustomers(ctx context.Context, req ListCustomersRequest) (*CustomerList, error) {
+	var resp struct {
+		Customers []squareCustomer `json:"customers"`
+		Cursor    string           `json:"cursor"`
+	}
+
+	if req.Email != "" {
+		body := map[string]interface{}{
+			"query": map[string]interface{}{
+				"filter": map[string]interface{}{
+					"email_address": map[string]interface{}{"exact": req.Email},
+				},
+			},
+		}
+		if req.Limit > 0 {
+			body["limit"] = req.Limit
+		}
+		if req.Cursor != "" {
+			body["cursor"] = req.Cursor
+		}
+		if err := g.do(ctx, http.MethodPost, "/v2/customers/search", nil, body, &resp); err != nil {
+			return nil, g.paymentError("customer_list_failed", "failed to search customers", err)
+		}
+	} else {
+		query := squarePageQuery(req.Cursor)
+		if req.Limit > 0 {
+			query.Set("limit", strconv.Itoa(req.Limit))
+		}
+		if err := g.do(ctx, http.MethodGet, "/v2/customers", query, nil, &resp); err != nil {
+			return nil, g.paymentError("customer_list_failed", "failed to list customers", err)
+		}
+	}
+
+	customers := make([]*Customer, len(resp.Customers))
+	for i := range resp.Customers {
+		customers[i] = convertSquareCustomer(&resp.Customers[i])
+	}
+
+	return &CustomerList{
+		Customers:  customers,
+		Total:      len(customers),
+		HasMore:    resp.Cursor != "",
+		NextCursor: resp.Cursor,
+	}, nil
+}
+
+// AddPaymentMethod saves a card on file. Square never accepts raw card
+// details from a server: metadata source_id must hold a Web Payments SDK
+// card token or the ID of a payment to save the card from.
+func (g *SquareGateway) AddPaymentMethod(ctx context.Context, customerID string, req AddPaymentMethodRequest) (*PaymentMethod, error) {
+	if req.Type != "" && req.Type != "card" {
+		return nil, g.notSupported("square only saves cards on file")
+	}
+	sourceID, _ := req.Metadata["source_id"].(string)
+	if sourceID == "" {
+		return nil, &PaymentError{Code: "invalid_request", Message: "metadata source_id is required to save a card with square", Provider: "square"}
+	}
+
+	card := map[string]interface{}{"customer_id": customerID}
+	if referenceID := squareReferenceID(req.Metadata); referenceID != "" {
+		card["reference_id"] = referenceID
+	}
+	body := map[string]interface{}{
+		"idempotency_key": uuid.New().String(),
+		"source_id":       sourceID,
+		"card":            card,
+	}
+
+	var resp struct {
+		Card squareCard `json:"card"`
+	}
+	if err := g.do(ctx, http.MethodPost, "/v2/cards", nil, body, &resp); err != nil {
+		return nil, g.paymentError("payment_method_creation_failed", "failed to save card", err)
+	}
+
+	return convertSquareCard(&resp.Card), nil
+}
+
+// RemovePaymentMethod disables a card on file; Square cards cannot be deleted
+func (g *SquareGateway) RemovePaymentMethod(ctx context.Context, customerID string, paymentMethodID string) error {
+	if err := g.do(ctx, http.MethodPost, "/v2/cards/"+url.PathEscape(paymentMethodID)+"/disable", nil, map[string]interface{}{}, nil); err != nil {
+		return g.paymentError("payment_method_removal_failed", "failed to disable card", err)
+	}
+
+	return nil
+}
+
+// ListPaymentMethods lists the customer's enabled cards. Square cannot limit
+// how many cards it lists, so pages are as long as Square makes them.
+func (g *SquareGateway) ListPaymentMethods(ctx context.Context, customerID string, req ListPaymentMethodsRequest) (*PaymentMethodList, error) {
+	query := squarePageQuery(req.Cursor)
+	query.Set("customer_id", customerID)
+
+	var resp struct {
+		Cards  []squareCard `json:"cards"`
+		Cursor string       `json:"cursor"`
+	}
+	if err := g.do(ctx, http.MethodGet, "/v2/cards", query, nil, &resp); err != nil {
+		return nil, g.paymentError("payment_method_list_failed", "failed to list cards", err)
+	}
+
+	paymentMethods := make([]*PaymentMethod, 0, len(resp.Cards))
+	for i := range resp.Cards {
+		if resp.Cards[i].Enabled {
+			paymentMethods = append(paymentMethods, convertSquareCard(&resp.Cards[i]))
+		}
+	}
+
+	return &PaymentMethodList{
+		PaymentMethods: paymentMethods,
+		HasMore:        resp.Cursor != "",
+		NextCursor:     resp.Cursor,
+	}, nil
+}
+
+// Payment processing implementation
+
+// CreateCharge creates a payment from a card on file or a card token. A
+// charge that is not captured is approved and must be completed later.
+func (g *SquareGateway) CreateCharge(ctx context.Context, req CreateChargeRequest) (*Charge, error) {
+	if err := receipts.ValidateDescriptor("square", req.StatementDescriptor, req.StatementDescriptorSuffix); err != nil {
+		return nil, &PaymentError{Code: "invalid_request", Message: err.Error(), Provider: "square"}
+	}
+
+	body := map[string]interface{}{
+		"idempotency_key": uuid.New().String(),
+		"source_id":       req.PaymentMethodID,
+		"amount_money":    squareMoney{Amount: req.Amount, Currency: strings.ToUpper(req.Currency)},
+		"autocomplete":    req.Capture,
+	}
+	if req.CustomerID != "" {
+		body["customer_id"] = req.CustomerID
+	}
+	if req.Description != "" {
+		body["note"] = req.Description
+	}
+	if referenceID := squareReferenceID(req.Metadata); referenceID != "" {
+		body["reference_id"] = referenceID
+	}
+	if g.locationID != "" {
+		body["location_id"] = g.locationID
+	}
+	// The identifier follows the seller's name on the statement
+	if req.StatementDescriptorSuffix != "" {
+		body["statement_description_identifier"] = req.StatementDescriptorSuffix
+	}
+	if req.ReceiptEmail != "" {
+		body["buyer_email_address"] = req.ReceiptEmail
+	}
+
+	var resp struct {
+		Payment squarePayment `json:"payment"`
+	}
+	if err := g.do(ctx, http.MethodPost, "/v2/payments", nil, body, &resp); err != nil {
+		return nil, g.paymentError("charge_creation_failed", "failed to create charge", err)
+	}
+
+	return convertSquarePayment(&resp.Payment), nil
+}
+
+func (g *SquareGateway) GetCharge(ctx context.Context, chargeID string) (*Charge, error) {
+	payment, err := g.getPayment(ctx, chargeID)
+	if err != nil {
+		return nil, g.paymentError("charge_retrieval_failed", "failed to retrieve charge", err)
+	}
+
+	return convertSquarePayment(payment), nil
+}
+
+// UpdateCharge is not supported: a Square payment's note and reference are
+// fixed once it is created
+func (g *SquareGateway) UpdateCharge(ctx context.Context, chargeID string, req UpdateChargeRequest) (*Charge, error) {
+	return nil, g.notSupported("square payments cannot be updated")
+}
+
+// CaptureCharge completes an approved payment. A smaller amount is set on
+// the payment before it is completed. Square payments are completed once.
+func (g *SquareGateway) CaptureCharge(ctx context.Context, chargeID string, req CaptureChargeRequest) (*Charge, error) {
+	if req.KeepOpen {
+		return nil, g.notSupported("square payments are captured once")
+	}
+	if req.Amount > 0 {
+		payment, err := g.getPayment(ctx, chargeID)
+		if err != nil {
+			return nil, g.paymentError("charge_capture_failed", "failed to retrieve charge", err)
+		}
+
+		if req.Amount != payment.AmountMoney.Amount {
+			body := map[string]interface{}{
+				"idempotency_key": uuid.New().String(),
+				"payment": map[string]interface{}{
+					"amount_money": squareMoney{Amount: req.Amount, Currency: payment.AmountMoney.Currency},
+				},
+			}
+			if err := g.do(ctx, http.MethodPut, "/v2/payments/"+url.PathEscape(chargeID), nil, body, nil); err != nil {
+				return nil, g.paymentError("charge_capture_failed", "failed to set capture amount", err)
+			}
+		}
+	}
+
+	var resp struct {
+		Payment squarePayment `json:"payment"`
+	}
+	if err := g.do(ctx, http.MethodPost, "/v2/payments/"+url.PathEscape(chargeID)+"/complete", nil, map[string]interface{}{}, &resp); err != nil {
+		return nil, g.paymentError("charge_capture_failed", "failed to capture charge", err)
+	}
+
+	return convertSquarePayment(&resp.Payment), nil
+}
+
+// VoidCharge cancels an approved payment, releasing its authorization
+func (g *SquareGateway) VoidCharge(ctx context.Context, chargeID string) (*Charge, error) {
+	var resp struct {
+		Payment squarePayment `json:"payment"`
+	}
+	if err := g.do(ctx, http.MethodPost, "/v2/payments/"+url.PathEscape(chargeID)+"/cancel", nil, map[string]interface{}{}, &resp); err != nil {
+		return nil, g.paymentError("charge_void_failed", "failed to void charge", err)
+	}
+
+	return convertSquarePayment(&resp.Payment), nil
+}
+
+// ListCharges lists recent payments. Square cannot filter payments by
+// customer or status, so filtered lists fetch full pages and filter them
+// here, which can leave a page with fewer charges than the limit.
+func (g *SquareGateway) ListCharges(ctx context.Context, req ListChargesRequest) (*ChargeList, error) {
+	query := squarePageQuery(req.Cursor)
+	query.Set("limit", strconv.Itoa(squarePageLimit(req.Limit, req.CustomerID != "" || req.Status != "")))
+	query.Set("sort_order", "DESC")
+	if g.locationID != "" {
+		query.Set("location_id", g.locationID)
+	}
+
+	var resp struct {
+		Payments []squarePayment `json:"payments"`
+		Cursor   string          `json:"cursor"`
+	}
+	if err := g.do(ctx, http.MethodGet, "/v2/payments", query, nil, &resp); err != nil {
+		return nil, g.paymentError("charge_list_failed", "failed to list charges", err)
+	}
+
+	charges := make([]*Charge, 0, len(resp.Payments))
+	for i := range resp.Payments {
+		charge := convertSquarePayment(&resp.Payments[i])
+		if req.CustomerID != "" && charge.CustomerID != req.CustomerID {
+			continue
+		}
+		if req.Status != "" && charge.Status != req.Status {
+			continue
+		}
+		charges = append(charges, charge)
+	}
+
+	return &ChargeList{
+		Charges:    charges,
+		Total:      len(charges),
+		HasMore:    resp.Cursor != "",
+		NextCursor: resp.Cursor,
+	}, nil
+}
+
+// Refund processing implementation
+
+// CreateRefund refunds a completed payment. Square needs an amount, so a
+// full refund is for whatever has not been refunded yet.
+func (g *SquareGateway) CreateRefund(ctx context.Context, req CreateRefundRequest) (*Refund, error) {
+	payment, err := g.getPayment(ctx, req.ChargeID)
+	if err != nil {
+		return nil, g.paymentError("refund_creation_failed", "failed to retrieve charge", err)
+	}
+
+	amount := req.Amount
+	if amount <= 0 {
+		amount = payment.TotalMoney.Amount
+		if payment.RefundedMoney != nil {
+			amount -= payment.RefundedMoney.Amount
+		}
+	}
+
+	body := map[string]interface{}{
+		"idempotency_key": uuid.New().String(),
+		"payment_id":      req.ChargeID,
+		"amount_money":    squareMoney{Amount: amount, Currency: payment.TotalMoney.Currency},
+	}
+	if req.Reason != "" {
+		body["reason"] = req.Reason
+	}
+
+	var resp struct {
+		Refund squareRefund `json:"refund"`
+	}
+	if err := g.do(ctx, http.MethodPost, "/v2/refunds", nil, body, &resp); err != nil {
+		return nil, g.paymentError("refund_creation_failed", "failed to create refund", err)
+	}
+
+	return convertSquareRefund(&resp.Refund), nil
+}
+
+func (g *SquareGateway) GetRefund(ctx context.Context, refundID string) (*Refund, error) {
+	var resp struct {
+		Refund squareRefund `json:"refund"`
+	}
+	if err := g.do(ctx, http.MethodGet, "/v2/refunds/"+url.PathEscape(refundID), nil, nil, &resp); err != nil {
+		return nil, g.paymentError("refund_retrieval_failed", "failed to retrieve refund", err)
+	}
+
+	return convertSquareRefund(&resp.Refund), nil
+}
+
+// UpdateRefund is not supported: Square refunds cannot be changed
+func (g *SquareGateway) UpdateRefund(ctx context.Context, refundID string, req UpdateRefundRequest) (*Refund, error) {
+	return nil, g.notSupported("square refunds cannot be updated")
+}
+
+// ListRefunds lists recent refunds, filtered here by charge since Square
+// cannot filter refunds by payment. Like charges, filtered pages can hold
+// fewer refunds than the limit.
+func (g *SquareGateway) ListRefunds(ctx context.Context, req ListRefundsRequest) (*RefundList, error) {
+	query := squarePageQuery(req.Cursor)
+	query.Set("limit", strconv.Itoa(squarePageLimit(req.Limit, req.ChargeID != "")))
+	query.Set("sort_order", "DESC")
+	if g.locationID != "" {
+		query.Set("location_id", g.locationID)
+	}
+
+	var resp struct {
+		Refunds []squareRefund `json:"refunds"`
+		Cursor  string         `json:"cursor"`
+	}
+	if err := g.do(ctx, http.MethodGet, "/v2/refunds", query, nil, &resp); err != nil {
+		return nil, g.paymentError("refund_list_failed", "failed to list refunds", err)
+	}
+
+	refunds := make([]*Refund, 0, len(resp.Refunds))
+	for i := range resp.Refunds {
+		if req.ChargeID != "" && resp.Refunds[i].PaymentID != req.ChargeID {
+			continue
+		}
+		refunds = append(refunds, convertSquareRefund(&resp.Refunds[i]))
+	}
+
+	return &RefundList{
+		Refunds:    refunds,
+		Total:      len(refunds),
+		HasMore:    resp.Cursor != "",
+		NextCursor: resp.Cursor,
+	}, nil
+}
+
+// Subscription management is not supported: Square subscriptions are
+// billed through its catalog and invoices rather than charged directly
+
+func (g *SquareGateway) CreateSubscription(ctx context.Context, req CreateSubscriptionRequest) (*Subscription, error) {
+	return nil, g.notSupported("subscriptions are not supported for square")
+}
+
+func (g *SquareGateway) GetSubscription(ctx context.Context, subscriptionID string) (*Subscription, error) {
+	return nil, g.notSupported("subscriptions are not supported for square")
+}
+
+func (g *SquareGateway) UpdateSubscription(ctx context.Context, subscriptionID string, req UpdateSubscriptionRequest) (*Subscription, error) {
+	return nil, g.notSupported("subscriptions are not supported for square")
+}
+
+func (g *SquareGateway) CancelSubscription(ctx context.Context, subscriptionID string, req CancelSubscriptionRequest) (*Subscription, error) {
+	return nil, g.notSupported("subscriptions are not supported for square")
+}
+
+func (g *SquareGateway) ListSubscriptions(ctx context.Context, req ListSubscriptionsRequest) (*SubscriptionList, error) {
+	return nil, g.notSupported("subscriptions are not supported for square")
+}
+
+// Dispute management implementation
+
+func (g *SquareGateway) GetDispute(ctx context.Context, disputeID string) (*Dispute, error) {
+	var resp struct {
+		Dispute squareDispute `json:"dispute"`
+	}
+	if err := g.do(ctx, http.MethodGet, "/v2/disputes/"+url.PathEscape(disputeID), nil, nil, &resp); err != nil {
+		return nil, g.paymentError("dispute_retrieval_failed", "failed to retrieve dispute", err)
+	}
+
+	return convertSquareDispute(&resp.Dispute), nil
+}
+
+// ListDisputes lists disputes in the given status, filtered here by charge.
+// Square cannot limit how many disputes it lists, so pages are as long as
+// Square makes them.
+func (g *SquareGateway) ListDisputes(ctx context.Context, req ListDisputesRequest) (*DisputeList, error) {
+	query := squarePageQuery(req.Cursor)
+	if req.Status != "" {
+		query.Set("states", strings.Join(squareDisputeStates(req.Status), ","))
+	}
+	if g.locationID != "" {
+		query.Set("location_id", g.locationID)
+	}
+
+	var resp struct {
+		Disputes []squareDispute `json:"disputes"`
+		Cursor   string          `json:"cursor"`
+	}
+	if err := g.do(ctx, http.MethodGet, "/v2/disputes", query, nil, &resp); err != nil {
+		return nil, g.paymentError("dispute_list_failed", "failed to list disputes", err)
+	}
+
+	disputes := make([]*Dispute, 0, len(resp.Disputes))
+	for i := range resp.Disputes {
+		if req.ChargeID != "" && resp.Disputes[i].DisputedPayment.PaymentID != req.ChargeID {
+			continue
+		}
+		disputes = append(disputes, convertSquareDispute(&resp.Disputes[i]))
+	}
+
+	return &DisputeList{
+		Disputes:   disputes,
+		Total:      len(disputes),
+		HasMore:    resp.Cursor != "",
+		NextCursor: resp.Cursor,
+	}, nil
+}
+
+func (g *SquareGateway) AcceptDispute(ctx context.Context, disputeID string) (*Dispute, error) {
+	var resp struct {
+		Dispute squareDispute `json:"dispute"`
+	}
+	if err := g.do(ctx, http.MethodPost, "/v2/disputes/"+url.PathEscape(disputeID)+"/accept", nil, map[string]interface{}{}, &resp); err != nil {
+		return nil, g.paymentError("dispute_accept_failed", "failed to accept dispute", err)
+	}
+
+	return convertSquareDispute(&resp.Dispute), nil
+}
+
+// SubmitDisputeEvidence adds text evidence to a dispute, then submits all
+// of the dispute's evidence to the bank when requested
+func (g *SquareGateway) SubmitDisputeEvidence(ctx context.Context, disputeID string, req SubmitDisputeEvidenceRequest) (*Dispute, error) {
+	path := "/v2/disputes/" + url.PathEscape(disputeID)
+
+	for _, evidence := range req.Evidence {
+		body := map[string]interface{}{
+			"idempotency_key": uuid.New().String(),
+			"evidence_type":   evidence.Type,
+			"evidence_text":   evidence.Text,
+		}
+		if err := g.do(ctx, http.MethodPost, path+"/evidence-text", nil, body, nil); err != nil {
+			return nil, g.paymentError("dispute_evidence_failed", "failed to add dispute evidence", err)
+		}
+	}
+
+	if !req.Submit {
+		return g.GetDispute(ctx, disputeID)
+	}
+
+	var resp struct {
+		Dispute squareDispute `json:"dispute"`
+	}
+	if err := g.do(ctx, http.MethodPost, path+"/submit-evidence", nil, map[string]interface{}{}, &resp); err != nil {
+		return nil, g.paymentError("dispute_evidence_failed", "failed to submit dispute evidence", err)
+	}
+
+	return convertSquareDispute(&resp.Dispute), nil
+}
+
+// Square API helpers
+
+// squareAPIError is the first of the errors Square returns with non-2xx responses
+type squareAPIError struct {
+	Status   int
+	Category string `json:"category"`
+	Code     string `json:"code"`
+	Detail   string `json:"detail"`
+	Field    string `json:"field"`
+}
+
+// HTTPStatus returns the status of the response Square returned the error with
+func (e *squareAPIError) HTTPStatus() int {
+	return e.Status
+}
+
+func (e *squareAPIError) Error() string {
+	message := fmt.Sprintf("square %s (%d): %s", e.Code, e.Status, e.Detail)
+	if e.Field != "" {
+		message += " [" + e.Field + "]"
+	}
+	return message
+}
+
+// do sends a request to the Square API and decodes the response into out
+// when it is not nil
+func (g *SquareGateway) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	endpoint := g.baseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+g.accessToken)
+	req.Header.Set("Square-Version", squareAPIVersion)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	payload, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		var failure struct {
+			Errors []squareAPIError `json:"errors"`
+		}
+		_ = json.Unmarshal(payload, &failure)
+
+		apiErr := &squareAPIError{Code: "http_error", Detail: http.StatusText(resp.StatusCode)}
+		if len(failure.Errors) > 0 {
+			apiErr = &failure.Errors[0]
+		}
+		apiErr.Status = resp.StatusCode
+		return apiErr
+	}
+
+	if out != nil && len(payload) > 0 {
+		if err := json.Unmarshal(payload, out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (g *SquareGateway) getPayment(ctx context.Context, paymentID string) (*squarePayment, error) {
+	var resp struct {
+		Payment squarePayment `json:"payment"`
+	}
+	if err := g.do(ctx, http.MethodGet, "/v2/payments/"+url.PathEscape(paymentID), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Payment, nil
+}
+
+func (g *SquareGateway) notSupported(message string) error {
+	return &PaymentError{Code: "not_supported", Message: message, Provider: "square"}
+}
+
+func (g *SquareGateway) paymentError(code, message string, err error) error {
+	return &PaymentError{
+		Code:     code,
+		Message:  fmt.Sprintf("%s: %v", message, err),
+		Provider: "square",
+	}
+}
+
+// splitSquareName splits a full name into Square's given and family names
+func splitSquareName(name string) (string, string) {
+	givenName, familyName, _ := strings.Cut(strings.TrimSpace(name), " ")
+	return givenName, strings.TrimSpace(familyName)
+}
+
+// squareReferenceID returns the reference_id metadata value, the only
+// metadata Square stores
+func squareReferenceID(metadata map[string]interface{}) string {
+	referenceID, _ := metadata["reference_id"].(string)
+	return referenceID
+}
+
+// squareMetadata returns the metadata carried by a Square reference ID
+func squareMetadata(referenceID string) map[string]interface{} {
+	if referenceID == "" {
+		return nil
+	}
+	return map[string]interface{}{"reference_id": referenceID}
+}
+
+// squarePageQuery continues a Square list from the opaque cursor returned
+// with its previous page
+func squarePageQuery(cursor string) url.Values {
+	query := url.Values{}
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	}
+	return query
+}
+
+// squarePageLimit returns how many objects to fetch for a page of limit.
+// Lists filtered after fetching take full pages, as a smaller page cut short
+// could not be continued from Square's cursor.
+func squarePageLimit(limit int, filtered bool) int {
+	if filtered || limit <= 0 {
+		return squareListLimit
+	}
+	return min(limit, squareListLimit)
+}
+
+func toSquareAddress(address *Address) *squareAddress {
+	if address == nil {
+		return nil
+	}
+	return &squareAddress{
+		AddressLine1:                 address.Line1,
+		AddressLine2:                 address.Line2,
+		Locality:                     address.City,
+		AdministrativeDistrictLevel1: address.State,
+		PostalCode:                   address.PostalCode,
+		Country:                      strings.ToUpper(address.Country),
+	}
+}
+
+// squareDisputeStates maps a common dispute status to Square dispute states
+func squareDisputeStates(status string) []string {
+	switch status {
+	case "needs_response":
+		return []string{"EVIDENCE_REQUIRED"}
+	case "under_review":
+		return []string{"PROCESSING"}
+	case "won":
+		return []string{"WON"}
+	case "lost":
+		return []string{"LOST", "ACCEPTED"}
+	case "warning_needs_response":
+		return []string{"INQUIRY_EVIDENCE_REQUIRED"}
+	case "warning_under_review":
+		return []string{"INQUIRY_PROCESSING"}
+	case "warning_closed":
+		return []string{"INQUIRY_CLOSED"}
+	default:
+		return []string{strings.ToUpper(status)}
+	}
+}
+
+// Conversion helper methods
+
+func convertSquareCustomer(sc *squareCustomer) *Customer {
+	customer := &Customer{
+		ID:         sc.ID,
+		Email:      sc.EmailAddress,
+		Name:       strings.TrimSpace(sc.GivenName + " " + sc.FamilyName),
+		Phone:      sc.PhoneNumber,
+		Metadata:   squareMetadata(sc.ReferenceID),
+		CreatedAt:  sc.CreatedAt,
+		UpdatedAt:  sc.UpdatedAt,
+		ProviderID: sc.ID,
+		Provider:   "square",
+	}
+
+	if sc.Address != nil {
+		customer.Address = &Address{
+			Line1:      sc.Address.AddressLine1,
+			Line2:      sc.Address.AddressLine2,
+			City:       sc.Address.Locality,
+			State:      sc.Address.AdministrativeDistrictLevel1,
+			PostalCode: sc.Address.PostalCode,
+			Country:    sc.Address.Country,
+		}
+	}
+
+	return customer
+}
+
+func convertSquareCard(sc *squareCard) *PaymentMethod {
+	return &PaymentMethod{
+		ID:         sc.ID,
+		CustomerID: sc.CustomerID,
+		Type:       "card",
+		Card: &Card{
+			Brand:       strings.ToLower(sc.CardBrand),
+			Last4:       sc.Last4,
+			ExpMonth:    sc.ExpMonth,
+			ExpYear:     sc.ExpYear,
+			Fingerprint: sc.Fingerprint,
+		},
+		Metadata:   squareMetadata(sc.ReferenceID),
+		CreatedAt:  sc.CreatedAt,
+		ProviderID: sc.ID,
+		Provider:   "square",
+	}
+}
+
+// convertSquarePayment converts a payment to a charge. Approved payments
+// are authorized and wait to be completed.
+func convertSquarePayment(sp *squarePayment) *Charge {
+	status := strings.ToLower(sp.Status)
+	switch sp.Status {
+	case "COMPLETED":
+		status = "succeeded"
+	case "APPROVED":
+		status = "requires_capture"
+	}
+
+	c := &Charge{
+		ID:          sp.ID,
+		Amount:      sp.AmountMoney.Amount,
+		Currency:    strings.ToLower(sp.AmountMoney.Currency),
+		CustomerID:  sp.CustomerID,
+		Status:      status,
+		Description: sp.Note,
+		Metadata:    squareMetadata(sp.ReferenceID),
+		CreatedAt:   sp.CreatedAt,
+		UpdatedAt:   sp.UpdatedAt,
+		ProviderID:  sp.ID,
+		Provider:    "square",
+
+		StatementDescriptor: sp.StatementDescriptionIdentifier,
+		ReceiptEmail:        sp.BuyerEmailAddress,
+		ReceiptURL:          sp.ReceiptURL,
+	}
+	if sp.CardDetails != nil {
+		c.PaymentMethodID = sp.CardDetails.Card.ID
+	}
+	if sp.Status == "APPROVED" {
+		c.CaptureBefore = sp.DelayedUntil
+	}
+
+	return c
+}
+
+// convertSquareRefund converts a refund. Rejected refunds count as failed.
+func convertSquareRefund(sr *squareRefund) *Refund {
+	status := strings.ToLower(sr.Status)
+	switch sr.Status {
+	case "COMPLETED":
+		status = "succeeded"
+	case "REJECTED":
+		status = "failed"
+	}
+
+	return &Refund{
+		ID:         sr.ID,
+		ChargeID:   sr.PaymentID,
+		Amount:     sr.AmountMoney.Amount,
+		Currency:   strings.ToLower(sr.AmountMoney.Currency),
+		Reason:     sr.Reason,
+		Status:     status,
+		CreatedAt:  sr.CreatedAt,
+		UpdatedAt:  sr.UpdatedAt,
+		ProviderID: sr.ID,
+		Provider:   "square",
+	}
+}
+
+// convertSquareDispute converts a dispute to the common statuses. An
+// accepted dispute is lost.
+func convertSquareDispute(sd *squareDispute) *Dispute {
+	status := strings.ToLower(sd.State)
+	switch sd.State {
+	case "EVIDENCE_REQUIRED":
+		status = "needs_response"
+	case "PROCESSING":
+		status = "under_review"
+	case "ACCEPTED", "LOST":
+		status = "lost"
+	case "INQUIRY_EVIDENCE_REQUIRED":
+		status = "warning_needs_response"
+	case "INQUIRY_PROCESSING":
+		status = "warning_under_review"
+	case "INQUIRY_CLOSED":
+		status = "warning_closed"
+	}
+
+	d := &Dispute{
+		ID:         sd.ID,
+		ChargeID:   sd.DisputedPayment.PaymentID,
+		Amount:     sd.AmountMoney.Amount,
+		Currency:   strings.ToLower(sd.AmountMoney.Currency),
+		Reason:     strings.ToLower(sd.Reason),
+		Status:     status,
+		CreatedAt:  sd.CreatedAt,
+		UpdatedAt:  sd.UpdatedAt,
+		ProviderID: sd.ID,
+		Provider:   "square",
+	}
+	if dueAt, err := time.Parse(time.RFC3339, sd.DueAt); err == nil {
+		d.EvidenceDueBy = dueAt
+	}
+
+	return d
+}
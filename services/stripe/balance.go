@@ -0,0 +1,185 @@
+package stripe
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/balancetransaction"
+	"github.com/stripe/stripe-go/v76/payout"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// BalanceService handles Stripe balance transaction and payout lookups
+type BalanceService struct {
+	tracer trace.Tracer
+}
+
+// NewBalanceService creates a new balance service
+func NewBalanceService() *BalanceService {
+	return &BalanceService{
+		tracer: otel.Tracer("payments.balance"),
+	}
+}
+
+// Balance transaction types matched against charges and refunds
+const (
+	BalanceTransactionCharge        = "charge"
+	BalanceTransactionPayment       = "payment"
+	BalanceTransactionRefund        = "refund"
+	BalanceTransactionPaymentRefund = "payment_refund"
+	BalanceTransactionPayout        = "payout"
+)
+
+// BalanceTransaction is a movement of funds in the Stripe balance. Fee is
+// what Stripe kept, so Net is Amount less Fee. Amounts are in the settlement
+// currency and negative for funds leaving the balance.
+type BalanceTransaction struct {
+	ID       string    `json:"id"`
+	Type     string    `json:"type"`
+	SourceID string    `json:"source_id,omitempty"` // The charge, refund or payout that moved the funds
+	Amount   int64     `json:"amount"`
+	Fee      int64     `json:"fee"`
+	Net      int64     `json:"net"`
+	Currency string    `json:"currency"`
+	Created  time.Time `json:"created"`
+}
+
+// BalanceTransactionFilter selects balance transactions created in
+// [CreatedFrom, CreatedTo), those paid out in one payout or those moved by
+// one charge or refund. Unset fields match everything.
+type BalanceTransactionFilter struct {
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	PayoutID    string
+	SourceID    string
+}
+
+// PayoutPaid is the status of a payout that has reached the bank
+const PayoutPaid = "paid"
+
+// Payout is a transfer from the Stripe balance to the merchant's bank account
+type Payout struct {
+	ID          string    `json:"id"`
+	Amount      int64     `json:"amount"`
+	Currency    string    `json:"currency"`
+	Status      string    `json:"status"`
+	ArrivalDate time.Time `json:"arrival_date"`
+	Created     time.Time `json:"created"`
+}
+
+// GetBalanceTransaction retrieves a balance transaction from Stripe
+func (s *BalanceService) GetBalanceTransaction(ctx context.Context, transactionID string) (*BalanceTransaction, error) {
+	ctx, span := s.tracer.Start(ctx, "GetBalanceTransaction")
+	defer span.End()
+
+	if transactionID == "" {
+		return nil, fmt.Errorf("balance transaction ID cannot be empty")
+	}
+
+	params := &stripe.BalanceTransactionParams{}
+	params.Context = ctx
+	params.Context = ctx
+	stripeTransaction, err := balancetransaction.Get(transactionID, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve balance transaction: %w", err)
+	}
+
+	return ConvertBalanceTransaction(stripeTransaction), nil
+}
+
+// ListBalanceTransactions lists every balance transaction matching a filter
+func (s *BalanceService) ListBalanceTransactions(ctx context.Context, filter BalanceTransactionFilter) ([]*BalanceTransaction, error) {
+	ctx, span := s.tracer.Start(ctx, "ListBalanceTransactions")
+	defer span.End()
+
+	params := &stripe.BalanceTransactionListParams{}
+	params.Context = ctx
+	if filter.PayoutID != "" {
+		params.Payout = stripe.String(filter.PayoutID)
+	}
+	if filter.SourceID != "" {
+		params.Source = stripe.String(filter.SourceID)
+	}
+	if !filter.CreatedFrom.IsZero() || !filter.CreatedTo.IsZero() {
+		params.CreatedRange = createdRange(filter.CreatedFrom, filter.CreatedTo)
+	}
+
+	params.Context = ctx
+	iter := balancetransaction.List(params)
+	var transactions []*BalanceTransaction
+	for iter.Next() {
+		transactions = append(transactions, ConvertBalanceTransaction(iter.BalanceTransaction()))
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list balance transactions: %w", err)
+	}
+
+	return transactions, nil
+}
+
+// ListPayouts lists every payout created in [from, to)
+func (s *BalanceService) ListPayouts(ctx context.Context, from, to time.Time) ([]*Payout, error) {
+	ctx, span := s.tracer.Start(ctx, "ListPayouts")
+	defer span.End()
+
+	params := &stripe.PayoutListParams{CreatedRange: createdRange(from, to)}
+	params.Context = ctx
+
+	params.Context = ctx
+	iter := payout.List(params)
+	var payouts []*Payout
+	for iter.Next() {
+		payouts = append(payouts, ConvertPayout(iter.Payout()))
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list payouts: %w", err)
+	}
+
+	return payouts, nil
+}
+
+// createdRange builds a [from, to) range filter, leaving zero bounds open
+func createdRange(from, to time.Time) *stripe.RangeQueryParams {
+	created := &stripe.RangeQueryParams{}
+	if !from.IsZero() {
+		created.GreaterThanOrEqual = from.Unix()
+	}
+	if !to.IsZero() {
+		created.LesserThan = to.Unix()
+	}
+	return created
+}
+
+// ConvertBalanceTransaction converts a Stripe balance transaction to our type
+func ConvertBalanceTransaction(stripeTransaction *stripe.BalanceTransaction) *BalanceTransaction {
+	transaction := &BalanceTransaction{
+		ID:       stripeTransaction.ID,
+		Type:     string(stripeTransaction.Type),
+		Amount:   stripeTransaction.Amount,
+		Fee:      stripeTransaction.Fee,
+		Net:      stripeTransaction.Net,
+		Currency: string(stripeTransaction.Currency),
+		Created:  time.Unix(stripeTransaction.Created, 0).UTC(),
+	}
+	if stripeTransaction.Source != nil {
+		transaction.SourceID = stripeTransaction.Source.ID
+	}
+	return transaction
+}
+
+// ConvertPayout converts a Stripe payout to our type
+func ConvertPayout(stripePayout *stripe.Payout) *Payout {
+	return &Payout{
+		ID:          stripePayout.ID,
+		Amount:      stripePayout.Amount,
+		Currency:    string(stripePayout.Currency),
+		Status:      string(stripePayout.Status),
+		ArrivalDate: time.Unix(stripePayout.ArrivalDate, 0).UTC(),
+		Created:     time.Unix(stripePayout.Created, 0).UTC(),
+	}
+}
@@ -0,0 +1,284 @@
+package stripe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/paymentmethod"
+	"github.com/stripe/stripe-go/v76/setupintent"
+)
+
+// Bank debit payment method types. Bank debits settle asynchronously: their
+// charges stay pending for days until the bank confirms or returns them.
+const (
+	PaymentMethodTypeACH  = "us_bank_account"
+	PaymentMethodTypeSEPA = "sepa_debit"
+	PaymentMethodTypeBACS = "bacs_debit"
+)
+
+var (
+	// ErrBankAccountRequired is returned when adding a bank debit without account details
+	ErrBankAccountRequired = errors.New("bank_account details are required for bank debit payment methods")
+	// ErrMandateRequired is returned when adding a bank debit without the customer's mandate acceptance
+	ErrMandateRequired = errors.New("bank debits require the customer's mandate acceptance")
+	// ErrInvalidMicrodeposits is returned for a verification with neither or both of amounts and a descriptor code
+	ErrInvalidMicrodeposits = errors.New("verify micro-deposits with either two amounts or a descriptor code")
+)
+
+// IsBankDebit reports whether a payment method type is a bank debit
+func IsBankDebit(paymentMethodType string) bool {
+	switch paymentMethodType {
+	case PaymentMethodTypeACH, PaymentMethodTypeSEPA, PaymentMethodTypeBACS:
+		return true
+	default:
+		return false
+	}
+}
+
+// BankAccountRequest represents the bank account of a bank debit payment
+// method. ACH takes the account and routing numbers, SEPA the IBAN and BACS
+// the account number and sort code. SEPA and BACS mandates also need the
+// holder's email, and BACS their address.
+type BankAccountRequest struct {
+	AccountHolderName string   `json:"account_holder_name" validate:"required"`
+	Email             string   `json:"email,omitempty" validate:"omitempty,email"`
+	Address           *Address `json:"address,omitempty"`
+	AccountNumber     string   `json:"account_number,omitempty"`
+	RoutingNumber     string   `json:"routing_number,omitempty"`
+	AccountHolderType string   `json:"account_holder_type,omitempty" validate:"omitempty,oneof=individual company"`
+	AccountType       string   `json:"account_type,omitempty" validate:"omitempty,oneof=checking savings"`
+	IBAN              string   `json:"iban,omitempty"`
+	SortCode          string   `json:"sort_code,omitempty"`
+}
+
+// MandateAcceptance records how the customer accepted a bank debit mandate.
+// Online acceptance needs the customer's IP address and user agent.
+type MandateAcceptance struct {
+	Type       string     `json:"type" validate:"required,oneof=online offline"`
+	IPAddress  string     `json:"ip_address,omitempty" validate:"required_if=Type online"`
+	UserAgent  string     `json:"user_agent,omitempty" validate:"required_if=Type online"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+}
+
+// BankAccount represents the bank account details of a bank debit payment method
+type BankAccount struct {
+	BankName          string `json:"bank_name,omitempty"`
+	Last4             string `json:"last4"`
+	Fingerprint       string `json:"fingerprint,omitempty"`
+	Country           string `json:"country,omitempty"`
+	RoutingNumber     string `json:"routing_number,omitempty"`
+	SortCode          string `json:"sort_code,omitempty"`
+	BankCode          string `json:"bank_code,omitempty"`
+	BranchCode        string `json:"branch_code,omitempty"`
+	AccountHolderType string `json:"account_holder_type,omitempty"`
+	AccountType       string `json:"account_type,omitempty"`
+}
+
+// Mandate represents a customer's authorization to debit a bank account
+type Mandate struct {
+	ID                string `json:"id"`
+	PaymentMethod     string `json:"payment_method"`
+	PaymentMethodType string `json:"payment_method_type,omitempty"`
+	Status            string `json:"status"`
+	Type              string `json:"type"`
+}
+
+// MicrodepositVerification verifies an ACH bank account with the two
+// micro-deposit amounts or the descriptor code of a single deposit
+type MicrodepositVerification struct {
+	Amounts        []int64 `json:"amounts,omitempty"`
+	DescriptorCode string  `json:"descriptor_code,omitempty"`
+}
+
+// addBankDebit creates a bank debit payment method and saves it with a
+// setup intent confirmed with the customer's mandate. Stripe attaches the
+// payment method once the intent succeeds; ACH accounts are first verified
+// with micro-deposits.
+func (s *CustomerService) addBankDebit(ctx context.Context, request *PaymentMethodRequest) (*PaymentMethod, error) {
+	if err := s.validator.Struct(request.BankAccount); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if err := s.validator.Struct(request.Mandate); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	account := request.BankAccount
+	params := &stripe.PaymentMethodParams{
+		Type: stripe.String(request.Type),
+		BillingDetails: &stripe.PaymentMethodBillingDetailsParams{
+			Name:    stripe.String(account.AccountHolderName),
+			Address: addressParams(account.Address),
+		},
+		Metadata: request.Metadata,
+	}
+	if account.Email != "" {
+		params.BillingDetails.Email = stripe.String(account.Email)
+	}
+	switch request.Type {
+	case PaymentMethodTypeACH:
+		params.USBankAccount = &stripe.PaymentMethodUSBankAccountParams{
+			AccountNumber: stripe.String(account.AccountNumber),
+			RoutingNumber: stripe.String(account.RoutingNumber),
+		}
+		if account.AccountHolderType != "" {
+			params.USBankAccount.AccountHolderType = stripe.String(account.AccountHolderType)
+		}
+		if account.AccountType != "" {
+			params.USBankAccount.AccountType = stripe.String(account.AccountType)
+		}
+	case PaymentMethodTypeSEPA:
+		params.SEPADebit = &stripe.PaymentMethodSEPADebitParams{IBAN: stripe.String(account.IBAN)}
+	case PaymentMethodTypeBACS:
+		params.BACSDebit = &stripe.PaymentMethodBACSDebitParams{
+			AccountNumber: stripe.String(account.AccountNumber),
+			SortCode:      stripe.String(account.SortCode),
+		}
+	}
+	params.Context = ctx
+
+	stripePaymentMethod, err := paymentmethod.New(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Stripe payment method: %w", err)
+	}
+
+	intentParams := &stripe.SetupIntentParams{
+		Customer:           stripe.String(request.Customer),
+		PaymentMethod:      stripe.String(stripePaymentMethod.ID),
+		PaymentMethodTypes: stripe.StringSlice([]string{request.Type}),
+		Usage:              stripe.String(string(stripe.SetupIntentUsageOffSession)),
+		Confirm:            stripe.Bool(true),
+		MandateData:        mandateData(request.Mandate),
+	}
+	// Account numbers entered by hand can't be verified instantly
+	if request.Type == PaymentMethodTypeACH {
+		intentParams.PaymentMethodOptions = &stripe.SetupIntentPaymentMethodOptionsParams{
+			USBankAccount: &stripe.SetupIntentPaymentMethodOptionsUSBankAccountParams{
+				VerificationMethod: stripe.String("microdeposits"),
+			},
+		}
+	}
+	intentParams.Context = ctx
+
+	stripeIntent, err := setupintent.New(intentParams)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up bank debit: %w", err)
+	}
+
+	paymentMethod := ConvertPaymentMethod(stripePaymentMethod)
+	paymentMethod.Customer = request.Customer
+	paymentMethod.SetupIntent = ConvertSetupIntent(stripeIntent)
+	s.mirror.SavePaymentMethod(ctx, paymentMethod)
+
+	return paymentMethod, nil
+}
+
+// VerifyMicrodeposits verifies the ACH bank account of a setup intent with
+// the micro-deposits Stripe sent to it
+func (s *CustomerService) VerifyMicrodeposits(ctx context.Context, setupIntentID string, verification *MicrodepositVerification) (*SetupIntent, error) {
+	ctx, span := s.tracer.Start(ctx, "VerifyMicrodeposits")
+	defer span.End()
+
+	if setupIntentID == "" {
+		return nil, fmt.Errorf("setup intent ID cannot be empty")
+	}
+	if (len(verification.Amounts) == 2) == (verification.DescriptorCode != "") {
+		return nil, ErrInvalidMicrodeposits
+	}
+
+	params := &stripe.SetupIntentVerifyMicrodepositsParams{}
+	if verification.DescriptorCode != "" {
+		params.DescriptorCode = stripe.String(verification.DescriptorCode)
+	} else {
+		params.Amounts = stripe.Int64Slice(verification.Amounts)
+	}
+	params.Context = ctx
+
+	stripeIntent, err := setupintent.VerifyMicrodeposits(setupIntentID, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify micro-deposits: %w", err)
+	}
+
+	return ConvertSetupIntent(stripeIntent), nil
+}
+
+// mandateData converts a mandate acceptance to Stripe mandate params
+func mandateData(acceptance *MandateAcceptance) *stripe.SetupIntentMandateDataParams {
+	if acceptance == nil {
+		return nil
+	}
+
+	customerAcceptance := &stripe.SetupIntentMandateDataCustomerAcceptanceParams{
+		Type: stripe.MandateCustomerAcceptanceType(acceptance.Type),
+	}
+	if acceptance.AcceptedAt != nil {
+		customerAcceptance.AcceptedAt = stripe.Int64(acceptance.AcceptedAt.Unix())
+	}
+	if acceptance.Type == string(stripe.MandateCustomerAcceptanceTypeOnline) {
+		customerAcceptance.Online = &stripe.SetupIntentMandateDataCustomerAcceptanceOnlineParams{
+			IPAddress: stripe.String(acceptance.IPAddress),
+			UserAgent: stripe.String(acceptance.UserAgent),
+		}
+	} else {
+		customerAcceptance.Offline = &stripe.SetupIntentMandateDataCustomerAcceptanceOfflineParams{}
+	}
+
+	return &stripe.SetupIntentMandateDataParams{CustomerAcceptance: customerAcceptance}
+}
+
+// convertBankAccount converts the bank details of a Stripe payment method,
+// or returns nil for payment methods that are not bank debits
+func convertBankAccount(stripePaymentMethod *stripe.PaymentMethod) *BankAccount {
+	switch {
+	case stripePaymentMethod.USBankAccount != nil:
+		account := stripePaymentMethod.USBankAccount
+		return &BankAccount{
+			BankName:          account.BankName,
+			Last4:             account.Last4,
+			Fingerprint:       account.Fingerprint,
+			Country:           "US",
+			RoutingNumber:     account.RoutingNumber,
+			AccountHolderType: string(account.AccountHolderType),
+			AccountType:       string(account.AccountType),
+		}
+	case stripePaymentMethod.SEPADebit != nil:
+		account := stripePaymentMethod.SEPADebit
+		return &BankAccount{
+			Last4:       account.Last4,
+			Fingerprint: account.Fingerprint,
+			Country:     account.Country,
+			BankCode:    account.BankCode,
+			BranchCode:  account.BranchCode,
+		}
+	case stripePaymentMethod.BACSDebit != nil:
+		account := stripePaymentMethod.BACSDebit
+		return &BankAccount{
+			Last4:       account.Last4,
+			Fingerprint: account.Fingerprint,
+			Country:     "GB",
+			SortCode:    account.SortCode,
+		}
+	default:
+		return nil
+	}
+}
+
+// ConvertMandate converts a Stripe mandate to our Mandate type
+func ConvertMandate(stripeMandate *stripe.Mandate) *Mandate {
+	mandate := &Mandate{
+		ID:     stripeMandate.ID,
+		Status: string(stripeMandate.Status),
+		Type:   string(stripeMandate.Type),
+	}
+
+	if stripeMandate.PaymentMethod != nil {
+		mandate.PaymentMethod = stripeMandate.PaymentMethod.ID
+	}
+	if stripeMandate.PaymentMethodDetails != nil {
+		mandate.PaymentMethodType = string(stripeMandate.PaymentMethodDetails.Type)
+	}
+
+	return mandate
+}
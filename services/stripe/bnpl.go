@@ -0,0 +1,336 @@
+package stripe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"apis/payments/services/money"
+
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/paymentintent"
+)
+
+// Buy-now-pay-later payment method types. The customer authorizes the
+// payment on the lender's page, so the payment intent waits in
+// requires_action until they are redirected back.
+const (
+	PaymentMethodTypeKlarna   = "klarna"
+	PaymentMethodTypeAfterpay = "afterpay_clearpay"
+)
+
+// Payment intent statuses
+const (
+	PaymentIntentRequiresPaymentMethod = "requires_payment_method"
+	PaymentIntentRequiresAction        = "requires_action"
+	PaymentIntentProcessing            = "processing"
+	PaymentIntentRequiresCapture       = "requires_capture"
+	PaymentIntentSucceeded             = "succeeded"
+	PaymentIntentCanceled              = "canceled"
+)
+
+// captureWindows is how long a BNPL authorization can be captured before the
+// lender releases it
+var captureWindows = map[string]time.Duration{
+	PaymentMethodTypeKlarna:   28 * 24 * time.Hour,
+	PaymentMethodTypeAfterpay: 13 * 24 * time.Hour,
+}
+
+var (
+	// ErrDateOfBirthRequired is returned for Klarna payments without the customer's date of birth
+	ErrDateOfBirthRequired = errors.New("klarna payments require the customer's date_of_birth")
+	// ErrShippingRequired is returned for Afterpay payments without a shipping address
+	ErrShippingRequired = errors.New("afterpay_clearpay payments require a shipping address")
+	// ErrNotCapturable is returned when capturing a payment intent that is not awaiting capture
+	ErrNotCapturable = errors.New("payment intent is not awaiting capture")
+	// ErrCaptureWindowExpired is returned when capturing an authorization the lender has released
+	ErrCaptureWindowExpired = errors.New("the authorization can no longer be captured")
+)
+
+// IsBNPL reports whether a payment method type is buy-now-pay-later
+func IsBNPL(paymentMethodType string) bool {
+	_, ok := captureWindows[paymentMethodType]
+	return ok
+}
+
+// CaptureWindow is how long after authorization a payment with the given
+// payment method type can be captured, or zero when it isn't limited here
+func CaptureWindow(paymentMethodType string) time.Duration {
+	return captureWindows[paymentMethodType]
+}
+
+// DateOfBirth is a customer's date of birth, which Klarna uses for its
+// credit check
+type DateOfBirth struct {
+	Day   int64 `json:"day" validate:"required,min=1,max=31"`
+	Month int64 `json:"month" validate:"required,min=1,max=12"`
+	Year  int64 `json:"year" validate:"required,min=1900"`
+}
+
+// BNPLCustomer holds the customer details lenders require: a name, email
+// and billing address for all of them, the date of birth for Klarna and a
+// shipping address for Afterpay
+type BNPLCustomer struct {
+	Name            string       `json:"name" validate:"required"`
+	Email           string       `json:"email" validate:"required,email"`
+	Phone           string       `json:"phone,omitempty"`
+	DateOfBirth     *DateOfBirth `json:"date_of_birth,omitempty"`
+	BillingAddress  *Address     `json:"billing_address" validate:"required"`
+	ShippingAddress *Address     `json:"shipping_address,omitempty"`
+}
+
+// PaymentIntentRequest represents a buy-now-pay-later payment. The customer
+// is sent to the payment intent's redirect_url and comes back to ReturnURL.
+// With a manual capture method the payment is only authorized and must be
+// captured within the lender's capture window, e.g. when the order ships.
+type PaymentIntentRequest struct {
+	Amount            int64             `json:"amount" validate:"required,min=1"`
+	AmountDecimal     string            `json:"amount_decimal,omitempty"`
+	Currency          string            `json:"currency" validate:"required"`
+	CustomerID        string            `json:"customer_id" validate:"required"`
+	PaymentMethodType string            `json:"payment_method_type" validate:"required,oneof=klarna afterpay_clearpay"`
+	ReturnURL         string            `json:"return_url" validate:"required,url"`
+	CaptureMethod     string            `json:"capture_method,omitempty" validate:"omitempty,oneof=automatic manual"`
+	Customer          *BNPLCustomer     `json:"customer" validate:"required"`
+	Description       string            `json:"description,omitempty"`
+	Metadata          map[string]string `json:"metadata,omitempty"`
+}
+
+// PaymentIntent represents a Stripe payment intent. RedirectURL is set while
+// the customer must authorize the payment with the lender; CaptureBefore
+// while an authorization awaits capture.
+type PaymentIntent struct {
+	ID                string            `json:"id"`
+	Amount            int64             `json:"amount"`
+	AmountCapturable  int64             `json:"amount_capturable"`
+	AmountReceived    int64             `json:"amount_received"`
+	Currency          string            `json:"currency"`
+	Status            string            `json:"status"`
+	CaptureMethod     string            `json:"capture_method"`
+	CustomerID        string            `json:"customer_id,omitempty"`
+	PaymentMethodType string            `json:"payment_method_type,omitempty"`
+	RedirectURL       string            `json:"redirect_url,omitempty"`
+	ChargeID          string            `json:"charge_id,omitempty"`
+	CaptureBefore     int64             `json:"capture_before,omitempty"`
+	LastError         string            `json:"last_error,omitempty"`
+	Description       string            `json:"description,omitempty"`
+	Metadata          map[string]string `json:"metadata,omitempty"`
+	Created           int64             `json:"created"`
+}
+
+// ValidatePaymentIntentRequest validates a BNPL payment request, including
+// the customer details its lender requires
+func (s *ChargeService) ValidatePaymentIntentRequest(request *PaymentIntentRequest) error {
+	if err := s.validator.Struct(request); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+	if err := money.ValidateAmount(request.Amount, request.Currency); err != nil {
+		return err
+	}
+
+	switch request.PaymentMethodType {
+	case PaymentMethodTypeKlarna:
+		if request.Customer.DateOfBirth == nil {
+			return ErrDateOfBirthRequired
+		}
+	case PaymentMethodTypeAfterpay:
+		if request.Customer.ShippingAddress == nil {
+			return ErrShippingRequired
+		}
+	}
+
+	return nil
+}
+
+// CreatePaymentIntent creates and confirms a buy-now-pay-later payment. The
+// returned intent requires action until the customer has authorized it at
+// RedirectURL.
+func (s *ChargeService) CreatePaymentIntent(ctx context.Context, request *PaymentIntentRequest) (*PaymentIntent, error) {
+	amount, err := money.ResolveAmount(request.Amount, request.AmountDecimal, request.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	request.Amount = amount
+
+	if err := s.ValidatePaymentIntentRequest(request); err != nil {
+		return nil, err
+	}
+
+	// Check that the customer is allowed to be charged
+	if err := s.ScreenCharge(ctx, request.CustomerID); err != nil {
+		return nil, err
+	}
+
+	customer := request.Customer
+	paymentMethodData := &stripe.PaymentIntentPaymentMethodDataParams{
+		Type: stripe.String(request.PaymentMethodType),
+		BillingDetails: &stripe.PaymentIntentPaymentMethodDataBillingDetailsParams{
+			Name:    stripe.String(customer.Name),
+			Email:   stripe.String(customer.Email),
+			Address: addressParams(customer.BillingAddress),
+		},
+	}
+	if customer.Phone != "" {
+		paymentMethodData.BillingDetails.Phone = stripe.String(customer.Phone)
+	}
+	switch request.PaymentMethodType {
+	case PaymentMethodTypeKlarna:
+		paymentMethodData.Klarna = &stripe.PaymentMethodKlarnaParams{
+			DOB: &stripe.PaymentMethodKlarnaDOBParams{
+				Day:   stripe.Int64(customer.DateOfBirth.Day),
+				Month: stripe.Int64(customer.DateOfBirth.Month),
+				Year:  stripe.Int64(customer.DateOfBirth.Year),
+			},
+		}
+	case PaymentMethodTypeAfterpay:
+		paymentMethodData.AfterpayClearpay = &stripe.PaymentMethodAfterpayClearpayParams{}
+	}
+
+	params := &stripe.PaymentIntentParams{
+		Amount:             stripe.Int64(request.Amount),
+		Currency:           stripe.String(request.Currency),
+		Customer:           stripe.String(request.CustomerID),
+		Description:        stripe.String(request.Description),
+		Metadata:           request.Metadata,
+		PaymentMethodTypes: stripe.StringSlice([]string{request.PaymentMethodType}),
+		PaymentMethodData:  paymentMethodData,
+		ReturnURL:          stripe.String(request.ReturnURL),
+		Confirm:            stripe.Bool(true),
+	}
+	if request.CaptureMethod == string(stripe.PaymentIntentCaptureMethodManual) {
+		params.CaptureMethod = stripe.String(request.CaptureMethod)
+	}
+	if customer.ShippingAddress != nil {
+		params.Shipping = &stripe.ShippingDetailsParams{
+			Name:    stripe.String(customer.Name),
+			Address: addressParams(customer.ShippingAddress),
+		}
+	}
+	params.AddExpand("latest_charge")
+	params.AddExpand("payment_method")
+	params.Context = ctx
+
+	stripeIntent, err := paymentintent.New(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create payment intent: %w", err)
+	}
+
+	return ConvertPaymentIntent(stripeIntent), nil
+}
+
+// GetPaymentIntent retrieves a payment intent, e.g. once the customer has
+// been redirected back from the lender
+func (s *ChargeService) GetPaymentIntent(ctx context.Context, paymentIntentID string) (*PaymentIntent, error) {
+	if paymentIntentID == "" {
+		return nil, fmt.Errorf("payment intent ID cannot be empty")
+	}
+
+	params := &stripe.PaymentIntentParams{}
+	params.AddExpand("latest_charge")
+	params.AddExpand("payment_method")
+	params.Context = ctx
+
+	stripeIntent, err := paymentintent.Get(paymentIntentID, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve payment intent: %w", err)
+	}
+
+	return ConvertPaymentIntent(stripeIntent), nil
+}
+
+// CapturePaymentIntent captures an authorized payment intent, in full when
+// amount is zero. Authorizations past their capture window are rejected
+// without calling Stripe.
+func (s *ChargeService) CapturePaymentIntent(ctx context.Context, paymentIntentID string, amount int64) (*PaymentIntent, error) {
+	intent, err := s.GetPaymentIntent(ctx, paymentIntentID)
+	if err != nil {
+		return nil, err
+	}
+	if intent.Status != PaymentIntentRequiresCapture {
+		return nil, ErrNotCapturable
+	}
+	if intent.CaptureBefore > 0 && time.Now().Unix() >= intent.CaptureBefore {
+		return nil, ErrCaptureWindowExpired
+	}
+
+	params := &stripe.PaymentIntentCaptureParams{}
+	if amount > 0 {
+		params.AmountToCapture = stripe.Int64(amount)
+	}
+	params.AddExpand("latest_charge")
+	params.AddExpand("payment_method")
+	params.Context = ctx
+
+	stripeIntent, err := paymentintent.Capture(paymentIntentID, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to capture payment intent: %w", err)
+	}
+	if stripeIntent.LatestCharge != nil {
+		s.mirror.SaveCharge(ctx, ConvertCharge(stripeIntent.LatestCharge))
+	}
+
+	return ConvertPaymentIntent(stripeIntent), nil
+}
+
+// CancelPaymentIntent cancels a payment intent that has not been captured,
+// releasing any authorization
+func (s *ChargeService) CancelPaymentIntent(ctx context.Context, paymentIntentID string) (*PaymentIntent, error) {
+	if paymentIntentID == "" {
+		return nil, fmt.Errorf("payment intent ID cannot be empty")
+	}
+
+	params := &stripe.PaymentIntentCancelParams{}
+	params.AddExpand("latest_charge")
+	params.AddExpand("payment_method")
+	params.Context = ctx
+
+	stripeIntent, err := paymentintent.Cancel(paymentIntentID, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel payment intent: %w", err)
+	}
+
+	return ConvertPaymentIntent(stripeIntent), nil
+}
+
+// ConvertPaymentIntent converts a Stripe payment intent to our PaymentIntent type
+func ConvertPaymentIntent(stripeIntent *stripe.PaymentIntent) *PaymentIntent {
+	intent := &PaymentIntent{
+		ID:               stripeIntent.ID,
+		Amount:           stripeIntent.Amount,
+		AmountCapturable: stripeIntent.AmountCapturable,
+		AmountReceived:   stripeIntent.AmountReceived,
+		Currency:         string(stripeIntent.Currency),
+		Status:           string(stripeIntent.Status),
+		CaptureMethod:    string(stripeIntent.CaptureMethod),
+		Description:      stripeIntent.Description,
+		Metadata:         stripeIntent.Metadata,
+		Created:          stripeIntent.Created,
+	}
+
+	if stripeIntent.Customer != nil {
+		intent.CustomerID = stripeIntent.Customer.ID
+	}
+	if stripeIntent.PaymentMethod != nil && stripeIntent.PaymentMethod.Type != "" {
+		intent.PaymentMethodType = string(stripeIntent.PaymentMethod.Type)
+	} else if len(stripeIntent.PaymentMethodTypes) == 1 {
+		intent.PaymentMethodType = stripeIntent.PaymentMethodTypes[0]
+	}
+	if stripeIntent.NextAction != nil && stripeIntent.NextAction.RedirectToURL != nil {
+		intent.RedirectURL = stripeIntent.NextAction.RedirectToURL.URL
+	}
+	if stripeIntent.LastPaymentError != nil {
+		intent.LastError = stripeIntent.LastPaymentError.Msg
+	}
+
+	// The authorization is held from when the charge was created
+	if stripeIntent.LatestCharge != nil {
+		intent.ChargeID = stripeIntent.LatestCharge.ID
+		window := CaptureWindow(intent.PaymentMethodType)
+		if intent.Status == PaymentIntentRequiresCapture && window > 0 {
+			intent.CaptureBefore = stripeIntent.LatestCharge.Created + int64(window/time.Second)
+		}
+	}
+
+	return intent
+}
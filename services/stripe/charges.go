@@ -2,92 +2,350 @@ package stripe
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strconv"
+	"runtime/trace"
 	"strings"
 
+	"apis/payments/services/money"
+	"apis/payments/services/receipts"
+
 	"github.com/go-playground/validator/v10"
 	"github.com/stripe/stripe-go/v76"
 	"github.com/stripe/stripe-go/v76/charge"
+	"github.com/stripe/stripe-go/v76/paymentintent"
+	"github.com/stripe/stripe-go/v76/paymentmethod"
+	"github.com/stripe/stripe-go/v76/refund"
 )
 
+// ErrPaymentMethodRequired is returned when a charge names neither a payment
+// method nor a legacy source
+var ErrPaymentMethodRequired = errors.New("payment_method is required")
+
+var (
+	// ErrMultiCaptureUnavailable is returned when leaving part of an
+	// authorization open for a charge that can only be captured once
+	ErrMultiCaptureUnavailable = errors.New("charge does not support multiple captures")
+	// ErrAlreadyCaptured is returned when voiding a charge captured in full
+	ErrAlreadyCaptured = errors.New("charge is already captured")
+)
+
+// ChargeGuard decides whether a customer may be charged
+type ChargeGuard interface {
+	// CheckCharge returns an error if new charges for the customer must be blocked
+	CheckCharge(ctx context.Context, customerID string) error
+}
+
 // ChargeService handles Stripe charge operations
 type ChargeService struct {
-	validator *validator.Validate
+	validator   *validator.Validate
+	guards      []ChargeGuard
+	credentials CredentialLog
+	mirror      Mirror
 }
 
 // NewChargeService creates a new charge service
 func NewChargeService() *ChargeService {
 	return &ChargeService{
 		validator: validator.New(),
+		mirror:    noMirror{},
 	}
 }
 
+// AddChargeGuard registers a guard that is consulted before every charge
+func (s *ChargeService) AddChargeGuard(guard ChargeGuard) {
+	s.guards = append(s.guards, guard)
+}
+
 // CreateCharge creates a new charge using Stripe
 func (s *ChargeService) CreateCharge(ctx context.Context, request *ChargeRequest) (*Charge, error) {
+	// Convert a decimal amount to minor units before validating
+	amount, err := money.ResolveAmount(request.Amount, request.AmountDecimal, request.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	request.Amount = amount
+
 	// Validate the request
 	if err := s.validator.Struct(request); err != nil {
 		return nil, fmt.Errorf("validation failed: %w", err)
 	}
 
-	// Additional business logic validation
-	if request.Amount <= 0 {
-		return nil, fmt.Errorf("amount must be positive")
+	// Additional business logic validation. Minimums depend on the
+	// currency's minor unit.
+	if err := money.ValidateAmount(request.Amount, request.Currency); err != nil {
+		return nil, err
+	}
+	if request.PaymentMethod == "" && request.Source == "" {
+		return nil, ErrPaymentMethodRequired
+	}
+	if !validApplicationFee(request) {
+		return nil, ErrInvalidApplicationFee
+	}
+	if err := receipts.ValidateDescriptor("stripe", request.StatementDescriptor, request.StatementDescriptorSuffix); err != nil {
+		return nil, err
 	}
 
-	// Convert to Stripe charge params
-	params := &stripe.ChargeParams{
-		Amount:      stripe.Int64(request.Amount),
-		Currency:    stripe.String(request.Currency),
-		Customer:    stripe.String(request.CustomerID),
-		Description: stripe.String(request.Description),
+	// Check that the customer is allowed to be charged
+	if err := s.ScreenCharge(ctx, request.CustomerID); err != nil {
+		return nil, err
 	}
 
-	// Set source using the proper method
-	if err := params.SetSource(request.Source); err != nil {
-		return nil, fmt.Errorf("failed to set source: %w", err)
+	// Requests still sending a legacy source are translated to a payment method
+	paymentMethodID := request.PaymentMethod
+	if paymentMethodID == "" {
+		paymentMethodID, err = s.PaymentMethodForSource(ctx, request.Source)
+		if err != nil {
+			return nil, err
+		}
 	}
 
+	// Charges are made with a PaymentIntent confirmed immediately. Like the
+	// Charges API, it fails rather than waiting for customer action.
+	params := &stripe.PaymentIntentParams{
+		Amount:                stripe.Int64(request.Amount),
+		Currency:              stripe.String(request.Currency),
+		Customer:              stripe.String(request.CustomerID),
+		Description:           stripe.String(request.Description),
+		Metadata:              request.Metadata,
+		PaymentMethod:         stripe.String(paymentMethodID),
+		Confirm:               stripe.Bool(true),
+		ErrorOnRequiresAction: stripe.Bool(true),
+		AutomaticPaymentMethods: &stripe.PaymentIntentAutomaticPaymentMethodsParams{
+			Enabled:        stripe.Bool(true),
+			AllowRedirects: stripe.String(string(stripe.PaymentIntentAutomaticPaymentMethodsAllowRedirectsNever)),
+		},
+	}
+	if request.CaptureMethod == string(stripe.PaymentIntentCaptureMethodManual) {
+		params.CaptureMethod = stripe.String(request.CaptureMethod)
+		if request.MultiCapture {
+			params.PaymentMethodOptions = &stripe.PaymentIntentPaymentMethodOptionsParams{
+				Card: &stripe.PaymentIntentPaymentMethodOptionsCardParams{
+					RequestMulticapture: stripe.String(string(stripe.PaymentIntentPaymentMethodOptionsCardRequestMulticaptureIfAvailable)),
+				},
+			}
+		}
+	}
+	// Destination charges settle to a connected account, less the platform's fee
+	if request.Destination != "" {
+		params.TransferData = &stripe.PaymentIntentTransferDataParams{
+			Destination: stripe.String(request.Destination),
+		}
+		if request.ApplicationFeeAmount > 0 {
+			params.ApplicationFeeAmount = stripe.Int64(request.ApplicationFeeAmount)
+		}
+	}
+	if request.StatementDescriptor != "" {
+		params.StatementDescriptor = stripe.String(request.StatementDescriptor)
+	}
+	if request.StatementDescriptorSuffix != "" {
+		params.StatementDescriptorSuffix = stripe.String(request.StatementDescriptorSuffix)
+	}
+	if request.ReceiptEmail != "" {
+		params.ReceiptEmail = stripe.String(request.ReceiptEmail)
+	}
+	params.AddExpand("latest_charge")
+	params.Context = ctx
+
 	// Create the charge
-	stripeCharge, err := charge.New(params)
+	providerRegion := trace.StartRegion(ctx, "stripeCreateCharge")
+	stripeIntent, err := paymentintent.New(params)
+	providerRegion.End()
 	if err != nil {
+		s.recordFailedCredential(ctx, err)
 		return nil, fmt.Errorf("failed to create Stripe charge: %w", err)
 	}
+	if stripeIntent.LatestCharge == nil {
+		return nil, fmt.Errorf("payment intent %s did not create a charge (status %s)", stripeIntent.ID, stripeIntent.Status)
+	}
+	stripeCharge := stripeIntent.LatestCharge
+
+	s.recordCredential(ctx, stripeCharge)
 
 	// Convert to our Charge type
-	charge := &Charge{
-		ID:          stripeCharge.ID,
-		Amount:      stripeCharge.Amount,
-		Currency:    string(stripeCharge.Currency),
-		Status:      string(stripeCharge.Status),
-		CustomerID:  stripeCharge.Customer.ID,
-		Description: stripeCharge.Description,
-		Created:     stripeCharge.Created,
-	}
+	charge := ConvertCharge(stripeCharge)
+	s.mirror.SaveCharge(ctx, charge)
 
 	return charge, nil
 }
 
+// ScreenCharge consults every charge guard, returning the first refusal
+func (s *ChargeService) ScreenCharge(ctx context.Context, customerID string) error {
+	defer trace.StartRegion(ctx, "chargeGuards").End()
+
+	for _, guard := range s.guards {
+		if err := guard.CheckCharge(ctx, customerID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // ChargeRequest represents a request to create a charge
 type ChargeRequest struct {
-	Amount      int64  `json:"amount" validate:"required,min=1"`
-	Currency    string `json:"currency" validate:"required"`
-	CustomerID  string `json:"customer_id" validate:"required"`
-	Description string `json:"description,omitempty"`
-	Source      string `json:"source" validate:"required"`
+	Amount        int64             `json:"amount" validate:"required,min=1"`
+	AmountDecimal string            `json:"amount_decimal,omitempty"` // Alternative to amount, e.g. "10.00"
+	Currency      string            `json:"currency" validate:"required"`
+	CustomerID    string            `json:"customer_id" validate:"required"`
+	Description   string            `json:"description,omitempty"`
+	PaymentMethod string            `json:"payment_method,omitempty"`
+	Source        string            `json:"source,omitempty"` // Deprecated: use PaymentMethod
+	Metadata      map[string]string `json:"metadata,omitempty"`
+	CaptureMethod string            `json:"capture_method,omitempty" validate:"omitempty,oneof=automatic manual"` // manual only authorizes the charge
+	MultiCapture  bool              `json:"multi_capture,omitempty"`                                              // lets a manual charge be captured in several parts, where the card allows it
+
+	// Destination is a connected account the charge settles to. The platform
+	// keeps ApplicationFeeAmount of it.
+	Destination          string `json:"destination,omitempty"`
+	ApplicationFeeAmount int64  `json:"application_fee_amount,omitempty" validate:"gte=0"`
+
+	// StatementDescriptor replaces the account's descriptor on the customer's
+	// card statement; StatementDescriptorSuffix is appended to it instead
+	StatementDescriptor       string `json:"statement_descriptor,omitempty"`
+	StatementDescriptorSuffix string `json:"statement_descriptor_suffix,omitempty"`
+	ReceiptEmail              string `json:"receipt_email,omitempty" validate:"omitempty,email"` // Stripe emails a receipt here once the charge succeeds
+
+	// FallbackPaymentMethods are tried in order when the payment method is
+	// declined. They are set from the customer's backup payment methods.
+	FallbackPaymentMethods []string `json:"-"`
+}
+
+// validApplicationFee reports whether a charge's application fee, if any, can
+// be collected: only destination charges carry one, and it must leave the
+// connected account something
+func validApplicationFee(request *ChargeRequest) bool {
+	if request.ApplicationFeeAmount == 0 {
+		return true
+	}
+	return request.Destination != "" && request.ApplicationFeeAmount < request.Amount
+}
+
+// PaymentMethodForSource translates a legacy source to an ID PaymentIntents
+// accept. Card tokens (tok_) are single-use and become a new PaymentMethod;
+// stored card (card_) and source (src_) IDs are accepted as they are.
+func (s *ChargeService) PaymentMethodForSource(ctx context.Context, source string) (string, error) {
+	if !strings.HasPrefix(source, "tok_") {
+		return source, nil
+	}
+
+	stripePaymentMethod, err := paymentmethod.New(&stripe.PaymentMethodParams{
+		Params: stripe.Params{Context: ctx},
+		Type:   stripe.String(string(stripe.PaymentMethodTypeCard)),
+		Card: &stripe.PaymentMethodCardParams{
+			Token: stripe.String(source),
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to convert card token to a payment method: %w", err)
+	}
+
+	return stripePaymentMethod.ID, nil
 }
 
 // Charge represents a Stripe charge
 type Charge struct {
-	ID              string            `json:"id"`
-	Amount          int64             `json:"amount"`
-	Currency        string            `json:"currency"`
-	Status          string            `json:"status"`
-	CustomerID      string            `json:"customer_id"`
-	PaymentMethodID string            `json:"payment_method_id,omitempty"`
-	Description     string            `json:"description"`
-	Metadata        map[string]string `json:"metadata,omitempty"`
-	Created         int64             `json:"created"`
+	ID                   string            `json:"id"`
+	Amount               int64             `json:"amount"`
+	AmountDecimal        string            `json:"amount_decimal"`
+	AmountDisplay        money.Display     `json:"amount_display"`
+	AmountRefunded       int64             `json:"amount_refunded"`
+	AmountCaptured       int64             `json:"amount_captured,omitempty"`
+	Currency             string            `json:"currency"`
+	Status               string            `json:"status"`
+	Captured             bool              `json:"captured"`
+	Refunded             bool              `json:"refunded"`
+	Disputed             bool              `json:"disputed"`
+	CustomerID           string            `json:"customer_id"`
+	PaymentMethodID      string            `json:"payment_method_id,omitempty"`
+	PaymentMethodType    string            `json:"payment_method_type,omitempty"` // Bank debits stay pending until they settle
+	FailureCode          string            `json:"failure_code,omitempty"`
+	FailureMessage       string            `json:"failure_message,omitempty"`
+	InvoiceID            string            `json:"invoice_id,omitempty"`
+	Description          string            `json:"description"`
+	Metadata             map[string]string `json:"metadata,omitempty"`
+	RiskLevel            string            `json:"risk_level,omitempty"`
+	RiskScore            int64             `json:"risk_score,omitempty"`
+	CardNetwork          string            `json:"card_network,omitempty"`
+	CredentialType       string            `json:"credential_type,omitempty"` // network_token, dpan or pan
+	Wallet               string            `json:"wallet,omitempty"`          // apple_pay or google_pay when paid with a wallet
+	Destination          string            `json:"destination,omitempty"`     // Connected account of a destination charge
+	ApplicationFeeAmount int64             `json:"application_fee_amount,omitempty"`
+	CaptureBefore        int64             `json:"capture_before,omitempty"`       // When an uncaptured authorization lapses
+	MultiCapture         bool              `json:"multi_capture,omitempty"`        // The authorization can be captured in several parts
+	StatementDescriptor  string            `json:"statement_descriptor,omitempty"` // As printed on the customer's statement
+	ReceiptEmail         string            `json:"receipt_email,omitempty"`
+	ReceiptURL           string            `json:"receipt_url,omitempty"` // Stripe's hosted receipt page
+	ReceiptNumber        string            `json:"receipt_number,omitempty"`
+	Created              int64             `json:"created"`
+}
+
+// ConvertCharge converts a Stripe charge to our Charge type
+func ConvertCharge(stripeCharge *stripe.Charge) *Charge {
+	charge := &Charge{
+		ID:              stripeCharge.ID,
+		Amount:          stripeCharge.Amount,
+		AmountDecimal:   money.FormatDecimal(stripeCharge.Amount, string(stripeCharge.Currency)),
+		AmountDisplay:   money.Describe(stripeCharge.Amount, string(stripeCharge.Currency)),
+		AmountRefunded:  stripeCharge.AmountRefunded,
+		AmountCaptured:  stripeCharge.AmountCaptured,
+		Currency:        string(stripeCharge.Currency),
+		Status:          string(stripeCharge.Status),
+		Captured:        stripeCharge.Captured,
+		Refunded:        stripeCharge.Refunded,
+		Disputed:        stripeCharge.Disputed,
+		PaymentMethodID: stripeCharge.PaymentMethod,
+		FailureCode:     stripeCharge.FailureCode,
+		FailureMessage:  stripeCharge.FailureMessage,
+		Description:     stripeCharge.Description,
+		Metadata:        stripeCharge.Metadata,
+		ReceiptEmail:    stripeCharge.ReceiptEmail,
+		ReceiptURL:      stripeCharge.ReceiptURL,
+		ReceiptNumber:   stripeCharge.ReceiptNumber,
+		Created:         stripeCharge.Created,
+	}
+
+	// The calculated descriptor includes the suffix and the account's prefix
+	charge.StatementDescriptor = stripeCharge.CalculatedStatementDescriptor
+	if charge.StatementDescriptor == "" {
+		charge.StatementDescriptor = stripeCharge.StatementDescriptor
+	}
+
+	if stripeCharge.Customer != nil {
+		charge.CustomerID = stripeCharge.Customer.ID
+	}
+
+	if stripeCharge.Invoice != nil {
+		charge.InvoiceID = stripeCharge.Invoice.ID
+	}
+
+	if stripeCharge.PaymentMethodDetails != nil {
+		charge.PaymentMethodType = string(stripeCharge.PaymentMethodDetails.Type)
+		if card := stripeCharge.PaymentMethodDetails.Card; card != nil {
+			charge.CaptureBefore = card.CaptureBefore
+			charge.MultiCapture = card.Multicapture != nil && card.Multicapture.Status == stripe.ChargePaymentMethodDetailsCardMulticaptureStatusAvailable
+		}
+	}
+
+	if stripeCharge.TransferData != nil && stripeCharge.TransferData.Destination != nil {
+		charge.Destination = stripeCharge.TransferData.Destination.ID
+		charge.ApplicationFeeAmount = stripeCharge.ApplicationFeeAmount
+	}
+
+	// Radar risk assessment, if available
+	if stripeCharge.Outcome != nil {
+		charge.RiskLevel = stripeCharge.Outcome.RiskLevel
+		charge.RiskScore = stripeCharge.Outcome.RiskScore
+	}
+
+	// Card credential used to authorize the charge
+	if credential := chargeCredential(stripeCharge); credential != nil {
+		charge.CardNetwork = credential.Network
+		charge.CredentialType = credential.CredentialType
+		charge.Wallet = credential.Wallet
+	}
+
+	return charge
 }
 
 // ValidateChargeRequest validates a charge request
@@ -96,21 +354,22 @@ func (s *ChargeService) ValidateChargeRequest(request *ChargeRequest) error {
 		return fmt.Errorf("validation failed: %w", err)
 	}
 
-	// Additional business logic validation
-	if request.Amount <= 0 {
-		return fmt.Errorf("amount must be positive")
-	}
-
-	if request.Currency == "" {
-		return fmt.Errorf("currency is required")
+	// Additional business logic validation. Minimums depend on the
+	// currency's minor unit.
+	if err := money.ValidateAmount(request.Amount, request.Currency); err != nil {
+		return err
 	}
 
 	if request.CustomerID == "" {
 		return fmt.Errorf("customer_id is required")
 	}
 
-	if request.Source == "" {
-		return fmt.Errorf("source is required")
+	if request.PaymentMethod == "" && request.Source == "" {
+		return ErrPaymentMethodRequired
+	}
+
+	if !validApplicationFee(request) {
+		return ErrInvalidApplicationFee
 	}
 
 	return nil
@@ -123,48 +382,31 @@ func (s *ChargeService) GetCharge(ctx context.Context, chargeID string) (*Charge
 	}
 
 	params := &stripe.ChargeParams{}
+	params.Context = ctx
 
 	stripeCharge, err := charge.Get(chargeID, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve charge: %w", err)
 	}
 
-	charge := &Charge{
-		ID:          stripeCharge.ID,
-		Amount:      stripeCharge.Amount,
-		Currency:    string(stripeCharge.Currency),
-		Status:      string(stripeCharge.Status),
-		CustomerID:  stripeCharge.Customer.ID,
-		Description: stripeCharge.Description,
-		Created:     stripeCharge.Created,
-	}
-
-	return charge, nil
+	return ConvertCharge(stripeCharge), nil
 }
 
-// ListCharges retrieves a list of charges
-func (s *ChargeService) ListCharges(ctx context.Context, customerID string, limit int64) ([]*Charge, error) {
+// ListCharges retrieves a page of charges, newest first
+func (s *ChargeService) ListCharges(ctx context.Context, customerID string, page Page) ([]*Charge, error) {
 	params := &stripe.ChargeListParams{}
 
 	if customerID != "" {
 		params.Customer = stripe.String(customerID)
 	}
+	page.apply(&params.ListParams)
+	params.Context = ctx
 
 	iter := charge.List(params)
 	var charges []*Charge
 
-	for iter.Next() {
-		stripeCharge := iter.Charge()
-		charge := &Charge{
-			ID:          stripeCharge.ID,
-			Amount:      stripeCharge.Amount,
-			Currency:    string(stripeCharge.Currency),
-			Status:      string(stripeCharge.Status),
-			CustomerID:  stripeCharge.Customer.ID,
-			Description: stripeCharge.Description,
-			Created:     stripeCharge.Created,
-		}
-		charges = append(charges, charge)
+	for !page.full(len(charges)) && iter.Next() {
+		charges = append(charges, ConvertCharge(iter.Charge()))
 	}
 
 	if err := iter.Err(); err != nil {
@@ -174,22 +416,139 @@ func (s *ChargeService) ListCharges(ctx context.Context, customerID string, limi
 	return charges, nil
 }
 
-// FormatAmount formats an amount in cents to a human-readable string
-func (s *ChargeService) FormatAmount(amount int64, currency string) string {
-	// Convert cents to dollars
-	dollars := float64(amount) / 100.0
+// UpdateCharge updates the description and metadata of a charge. Empty
+// values are left unchanged.
+func (s *ChargeService) UpdateCharge(ctx context.Context, chargeID, description string, metadata map[string]string) (*Charge, error) {
+	if chargeID == "" {
+		return nil, fmt.Errorf("charge ID cannot be empty")
+	}
+
+	params := &stripe.ChargeParams{Metadata: metadata}
+	if description != "" {
+		params.Description = stripe.String(description)
+	}
+	params.Context = ctx
+
+	stripeCharge, err := charge.Update(chargeID, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update charge: %w", err)
+	}
+
+	updated := ConvertCharge(stripeCharge)
+	s.mirror.SaveCharge(ctx, updated)
+
+	return updated, nil
+}
+
+// CaptureCharge captures an authorized charge, in full when amount is zero.
+// Charges made with a PaymentIntent are captured through it.
+func (s *ChargeService) CaptureCharge(ctx context.Context, chargeID string, amount int64) (*Charge, error) {
+	return s.CaptureChargePart(ctx, chargeID, amount, true)
+}
+
+// CaptureChargePart captures amount of an authorized charge. Unless final,
+// the rest of a multi-capture authorization stays open for further
+// captures; other charges can only be captured once.
+func (s *ChargeService) CaptureChargePart(ctx context.Context, chargeID string, amount int64, final bool) (*Charge, error) {
+	if chargeID == "" {
+		return nil, fmt.Errorf("charge ID cannot be empty")
+	}
+
+	stripeCharge, err := charge.Get(chargeID, &stripe.ChargeParams{Params: stripe.Params{Context: ctx}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve charge: %w", err)
+	}
+	if !final && (stripeCharge.PaymentIntent == nil || !ConvertCharge(stripeCharge).MultiCapture) {
+		return nil, ErrMultiCaptureUnavailable
+	}
 
-	// Format based on currency
-	switch currency {
-	case "usd":
-		return fmt.Sprintf("$%.2f", dollars)
-	case "eur":
-		return fmt.Sprintf("€%.2f", dollars)
-	case "gbp":
-		return fmt.Sprintf("£%.2f", dollars)
-	default:
-		return fmt.Sprintf("%.2f %s", dollars, currency)
+	if stripeCharge.PaymentIntent != nil {
+		params := &stripe.PaymentIntentCaptureParams{}
+		if amount > 0 {
+			params.AmountToCapture = stripe.Int64(amount)
+		}
+		if !final {
+			params.FinalCapture = stripe.Bool(false)
+		}
+		params.AddExpand("latest_charge")
+		params.Context = ctx
+
+		stripeIntent, err := paymentintent.Capture(stripeCharge.PaymentIntent.ID, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to capture charge: %w", err)
+		}
+		if stripeIntent.LatestCharge != nil {
+			stripeCharge = stripeIntent.LatestCharge
+		}
+	} else {
+		params := &stripe.ChargeCaptureParams{}
+		if amount > 0 {
+			params.Amount = stripe.Int64(amount)
+		}
+		params.Context = ctx
+
+		stripeCharge, err = charge.Capture(chargeID, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to capture charge: %w", err)
+		}
 	}
+
+	captured := ConvertCharge(stripeCharge)
+	s.mirror.SaveCharge(ctx, captured)
+
+	return captured, nil
+}
+
+// VoidCharge releases an uncaptured authorization. Charges made with a
+// PaymentIntent are canceled through it; legacy charges are released by
+// refunding them before capture.
+func (s *ChargeService) VoidCharge(ctx context.Context, chargeID string) (*Charge, error) {
+	if chargeID == "" {
+		return nil, fmt.Errorf("charge ID cannot be empty")
+	}
+
+	stripeCharge, err := charge.Get(chargeID, &stripe.ChargeParams{Params: stripe.Params{Context: ctx}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve charge: %w", err)
+	}
+	if stripeCharge.Captured && stripeCharge.AmountCaptured >= stripeCharge.Amount {
+		return nil, ErrAlreadyCaptured
+	}
+
+	if stripeCharge.PaymentIntent != nil {
+		params := &stripe.PaymentIntentCancelParams{}
+		params.AddExpand("latest_charge")
+		params.Context = ctx
+
+		stripeIntent, err := paymentintent.Cancel(stripeCharge.PaymentIntent.ID, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to void charge: %w", err)
+		}
+		if stripeIntent.LatestCharge != nil {
+			stripeCharge = stripeIntent.LatestCharge
+		}
+	} else {
+		params := &stripe.RefundParams{Charge: stripe.String(chargeID)}
+		params.Context = ctx
+
+		if _, err := refund.New(params); err != nil {
+			return nil, fmt.Errorf("failed to void charge: %w", err)
+		}
+		stripeCharge, err = charge.Get(chargeID, &stripe.ChargeParams{Params: stripe.Params{Context: ctx}})
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve charge: %w", err)
+		}
+	}
+
+	voided := ConvertCharge(stripeCharge)
+	s.mirror.SaveCharge(ctx, voided)
+
+	return voided, nil
+}
+
+// FormatAmount formats an amount in minor units to a human-readable string
+func (s *ChargeService) FormatAmount(amount int64, currency string) string {
+	return money.Describe(amount, currency).Formatted
 }
 
 // ParseAmount parses a human-readable amount string to cents
@@ -200,13 +559,11 @@ func (s *ChargeService) ParseAmount(amountStr string) (int64, error) {
 		cleanStr = strings.ReplaceAll(cleanStr, symbol, "")
 	}
 
-	// Parse as float
-	amount, err := strconv.ParseFloat(cleanStr, 64)
+	// The symbols handled here all belong to two-decimal currencies
+	cents, err := money.ParseDecimal(cleanStr, "usd")
 	if err != nil {
 		return 0, fmt.Errorf("invalid amount format: %w", err)
 	}
 
-	// Convert to cents
-	cents := int64(amount * 100)
 	return cents, nil
 }
@@ -0,0 +1,118 @@
+package stripe
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/client"
+	"github.com/stripe/stripe-go/v76/form"
+)
+
+// Configure sets the Stripe API key and the HTTP client used for every
+// Stripe API call made by this package
+func Configure(secretKey string, httpClient *http.Client) {
+	stripe.Key = secretKey
+
+	if httpClient != nil {
+		stripe.SetBackend(stripe.APIBackend, stripe.GetBackendWithConfig(stripe.APIBackend, &stripe.BackendConfig{
+			HTTPClient: httpClient,
+		}))
+	}
+}
+
+// VerifySecretKey makes an authenticated call with a secret key, leaving the
+// key used by the rest of this package unchanged
+func VerifySecretKey(ctx context.Context, secretKey string) error {
+	api := &client.API{}
+	api.Init(secretKey, nil)
+
+	params := &stripe.BalanceParams{}
+	params.Context = ctx
+	if _, err := api.Balance.Get(params); err != nil {
+		return fmt.Errorf("failed to verify stripe key: %w", err)
+	}
+
+	return nil
+}
+
+// KeySource picks the secret key for a call from the context it was made
+// with. ok is false when the call should use the configured key.
+type KeySource interface {
+	SecretKey(ctx context.Context) (key string, ok bool, err error)
+}
+
+// UseKeySource makes every Stripe API call made by this package with a
+// context use the key the source picks for it. Call it after Configure.
+func UseKeySource(source KeySource) {
+	stripe.SetBackend(stripe.APIBackend, &keyedBackend{
+		Backend: stripe.GetBackend(stripe.APIBackend),
+		source:  source,
+	})
+}
+
+// keyedBackend replaces the key of each call with the one its context picks
+type keyedBackend struct {
+	stripe.Backend
+	source KeySource
+}
+
+// Call makes a call with the key picked for its context
+func (b *keyedBackend) Call(method, path, key string, params stripe.ParamsContainer, v stripe.LastResponseSetter) error {
+	key, err := b.key(key, params)
+	if err != nil {
+		return err
+	}
+	return b.Backend.Call(method, path, key, params, v)
+}
+
+// CallStreaming makes a streaming call with the key picked for its context
+func (b *keyedBackend) CallStreaming(method, path, key string, params stripe.ParamsContainer, v stripe.StreamingLastResponseSetter) error {
+	key, err := b.key(key, params)
+	if err != nil {
+		return err
+	}
+	return b.Backend.CallStreaming(method, path, key, params, v)
+}
+
+// CallRaw makes a raw call with the key picked for its context
+func (b *keyedBackend) CallRaw(method, path, key string, body *form.Values, params *stripe.Params, v stripe.LastResponseSetter) error {
+	key, err := b.key(key, params)
+	if err != nil {
+		return err
+	}
+	return b.Backend.CallRaw(method, path, key, body, params, v)
+}
+
+// CallMultipart makes a multipart call with the key picked for its context
+func (b *keyedBackend) CallMultipart(method, path, key, boundary string, body *bytes.Buffer, params *stripe.Params, v stripe.LastResponseSetter) error {
+	key, err := b.key(key, params)
+	if err != nil {
+		return err
+	}
+	return b.Backend.CallMultipart(method, path, key, boundary, body, params, v)
+}
+
+// key returns the key picked for a call's context, or the given key
+func (b *keyedBackend) key(key string, params stripe.ParamsContainer) (string, error) {
+	// Calls without params pass them as a typed nil
+	if params == nil || reflect.ValueOf(params).IsNil() {
+		return key, nil
+	}
+	p := params.GetParams()
+	if p.Context == nil {
+		return key, nil
+	}
+
+	picked, ok, err := b.source.SecretKey(p.Context)
+	if err != nil {
+		return "", fmt.Errorf("failed to pick stripe key: %w", err)
+	}
+	if ok {
+		return picked, nil
+	}
+	return key, nil
+}
@@ -0,0 +1,334 @@
+package stripe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"apis/payments/services/money"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/account"
+	"github.com/stripe/stripe-go/v76/accountlink"
+	"github.com/stripe/stripe-go/v76/transfer"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Connected account types
+const (
+	AccountTypeExpress  = "express"
+	AccountTypeStandard = "standard"
+	AccountTypeCustom   = "custom"
+)
+
+// ErrInvalidApplicationFee is returned when a charge's application fee is
+// set without a destination or is not less than the charge amount
+var ErrInvalidApplicationFee = errors.New("application_fee_amount requires a destination and must be less than the amount")
+
+// ConnectService handles Stripe Connect operations: connected accounts,
+// their onboarding and transfers to them
+type ConnectService struct {
+	validator *validator.Validate
+	tracer    trace.Tracer
+}
+
+// NewConnectService creates a new Connect service
+func NewConnectService() *ConnectService {
+	return &ConnectService{
+		validator: validator.New(),
+		tracer:    otel.Tracer("payments.connect"),
+	}
+}
+
+// ConnectedAccount represents a Stripe connected account
+type ConnectedAccount struct {
+	ID               string            `json:"id"`
+	Type             string            `json:"type"`
+	Email            string            `json:"email,omitempty"`
+	Country          string            `json:"country"`
+	BusinessType     string            `json:"business_type,omitempty"`
+	ChargesEnabled   bool              `json:"charges_enabled"`
+	PayoutsEnabled   bool              `json:"payouts_enabled"`
+	DetailsSubmitted bool              `json:"details_submitted"`
+	CurrentlyDue     []string          `json:"currently_due,omitempty"` // Onboarding requirements still outstanding
+	DisabledReason   string            `json:"disabled_reason,omitempty"`
+	Metadata         map[string]string `json:"metadata,omitempty"`
+	Created          int64             `json:"created"`
+}
+
+// CreateAccountRequest creates a connected account for a marketplace seller
+type CreateAccountRequest struct {
+	Type         string            `json:"type,omitempty" validate:"omitempty,oneof=express standard custom"` // Defaults to express
+	Country      string            `json:"country" validate:"required,len=2"`
+	Email        string            `json:"email,omitempty" validate:"omitempty,email"`
+	BusinessType string            `json:"business_type,omitempty" validate:"omitempty,oneof=individual company non_profit government_entity"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+}
+
+// AccountLinkRequest requests a link to Stripe-hosted onboarding
+type AccountLinkRequest struct {
+	RefreshURL string `json:"refresh_url" validate:"required,url"` // Where an expired link sends the seller to get a new one
+	ReturnURL  string `json:"return_url" validate:"required,url"`
+}
+
+// AccountLink is a single-use link to Stripe-hosted onboarding
+type AccountLink struct {
+	AccountID string `json:"account_id"`
+	URL       string `json:"url"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// Transfer represents a transfer of funds to a connected account
+type Transfer struct {
+	ID                string            `json:"id"`
+	Amount            int64             `json:"amount"`
+	AmountDisplay     money.Display     `json:"amount_display"`
+	AmountReversed    int64             `json:"amount_reversed"`
+	Currency          string            `json:"currency"`
+	Destination       string            `json:"destination"`
+	SourceTransaction string            `json:"source_transaction,omitempty"`
+	TransferGroup     string            `json:"transfer_group,omitempty"`
+	Description       string            `json:"description,omitempty"`
+	Reversed          bool              `json:"reversed"`
+	Metadata          map[string]string `json:"metadata,omitempty"`
+	Created           int64             `json:"created"`
+}
+
+// TransferRequest moves funds from the platform balance to a connected account
+type TransferRequest struct {
+	Amount            int64             `json:"amount" validate:"required,gt=0"`
+	Currency          string            `json:"currency" validate:"required,len=3"`
+	Destination       string            `json:"destination" validate:"required"`
+	SourceTransaction string            `json:"source_transaction,omitempty"` // Charge the transfer is funded from
+	TransferGroup     string            `json:"transfer_group,omitempty"`
+	Description       string            `json:"description,omitempty"`
+	Metadata          map[string]string `json:"metadata,omitempty"`
+}
+
+// CreateAccount creates a connected account. Express and custom accounts
+// request card payments and transfers so they can receive destination
+// charges once onboarded; standard accounts manage their own capabilities.
+func (s *ConnectService) CreateAccount(ctx context.Context, request *CreateAccountRequest) (*ConnectedAccount, error) {
+	ctx, span := s.tracer.Start(ctx, "CreateAccount")
+	defer span.End()
+
+	if err := s.validator.Struct(request); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	accountType := request.Type
+	if accountType == "" {
+		accountType = AccountTypeExpress
+	}
+
+	params := &stripe.AccountParams{
+		Type:    stripe.String(accountType),
+		Country: stripe.String(request.Country),
+	}
+	if request.Email != "" {
+		params.Email = stripe.String(request.Email)
+	}
+	if request.BusinessType != "" {
+		params.BusinessType = stripe.String(request.BusinessType)
+	}
+	if len(request.Metadata) > 0 {
+		params.Metadata = request.Metadata
+	}
+	if accountType != AccountTypeStandard {
+		params.Capabilities = &stripe.AccountCapabilitiesParams{
+			CardPayments: &stripe.AccountCapabilitiesCardPaymentsParams{Requested: stripe.Bool(true)},
+			Transfers:    &stripe.AccountCapabilitiesTransfersParams{Requested: stripe.Bool(true)},
+		}
+	}
+
+	params.Context = ctx
+	stripeAccount, err := account.New(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create connected account: %w", err)
+	}
+
+	return ConvertConnectedAccount(stripeAccount), nil
+}
+
+// GetAccount retrieves a connected account by ID
+func (s *ConnectService) GetAccount(ctx context.Context, accountID string) (*ConnectedAccount, error) {
+	ctx, span := s.tracer.Start(ctx, "GetAccount")
+	defer span.End()
+
+	if accountID == "" {
+		return nil, fmt.Errorf("account ID cannot be empty")
+	}
+
+	stripeAccount, err := account.GetByID(accountID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve connected account: %w", err)
+	}
+
+	return ConvertConnectedAccount(stripeAccount), nil
+}
+
+// ListAccounts lists connected accounts, newest first
+func (s *ConnectService) ListAccounts(ctx context.Context, page Page) ([]*ConnectedAccount, error) {
+	ctx, span := s.tracer.Start(ctx, "ListAccounts")
+	defer span.End()
+
+	params := &stripe.AccountListParams{}
+	page.apply(&params.ListParams)
+
+	params.Context = ctx
+	iter := account.List(params)
+	var accounts []*ConnectedAccount
+
+	for !page.full(len(accounts)) && iter.Next() {
+		accounts = append(accounts, ConvertConnectedAccount(iter.Account()))
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list connected accounts: %w", err)
+	}
+
+	return accounts, nil
+}
+
+// CreateAccountLink creates a link to Stripe-hosted onboarding for a
+// connected account. Links expire after a few minutes and can be used once.
+func (s *ConnectService) CreateAccountLink(ctx context.Context, accountID string, request *AccountLinkRequest) (*AccountLink, error) {
+	ctx, span := s.tracer.Start(ctx, "CreateAccountLink")
+	defer span.End()
+
+	if accountID == "" {
+		return nil, fmt.Errorf("account ID cannot be empty")
+	}
+	if err := s.validator.Struct(request); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	link, err := accountlink.New(&stripe.AccountLinkParams{
+		Params:     stripe.Params{Context: ctx},
+		Account:    stripe.String(accountID),
+		RefreshURL: stripe.String(request.RefreshURL),
+		ReturnURL:  stripe.String(request.ReturnURL),
+		Type:       stripe.String("account_onboarding"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create onboarding link: %w", err)
+	}
+
+	return &AccountLink{
+		AccountID: accountID,
+		URL:       link.URL,
+		ExpiresAt: link.ExpiresAt,
+	}, nil
+}
+
+// CreateTransfer transfers funds from the platform balance to a connected account
+func (s *ConnectService) CreateTransfer(ctx context.Context, request *TransferRequest) (*Transfer, error) {
+	ctx, span := s.tracer.Start(ctx, "CreateTransfer")
+	defer span.End()
+
+	if err := s.validator.Struct(request); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	params := &stripe.TransferParams{
+		Amount:      stripe.Int64(request.Amount),
+		Currency:    stripe.String(request.Currency),
+		Destination: stripe.String(request.Destination),
+	}
+	if request.SourceTransaction != "" {
+		params.SourceTransaction = stripe.String(request.SourceTransaction)
+	}
+	if request.TransferGroup != "" {
+		params.TransferGroup = stripe.String(request.TransferGroup)
+	}
+	if request.Description != "" {
+		params.Description = stripe.String(request.Description)
+	}
+	if len(request.Metadata) > 0 {
+		params.Metadata = request.Metadata
+	}
+
+	params.Context = ctx
+	stripeTransfer, err := transfer.New(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transfer: %w", err)
+	}
+
+	return ConvertTransfer(stripeTransfer), nil
+}
+
+// ListTransfers lists transfers, newest first, optionally to one connected account
+func (s *ConnectService) ListTransfers(ctx context.Context, destination string, page Page) ([]*Transfer, error) {
+	ctx, span := s.tracer.Start(ctx, "ListTransfers")
+	defer span.End()
+
+	params := &stripe.TransferListParams{}
+	if destination != "" {
+		params.Destination = stripe.String(destination)
+	}
+	page.apply(&params.ListParams)
+
+	params.Context = ctx
+	iter := transfer.List(params)
+	var transfers []*Transfer
+
+	for !page.full(len(transfers)) && iter.Next() {
+		transfers = append(transfers, ConvertTransfer(iter.Transfer()))
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list transfers: %w", err)
+	}
+
+	return transfers, nil
+}
+
+// ConvertConnectedAccount converts a Stripe account to our ConnectedAccount type
+func ConvertConnectedAccount(stripeAccount *stripe.Account) *ConnectedAccount {
+	connected := &ConnectedAccount{
+		ID:               stripeAccount.ID,
+		Type:             string(stripeAccount.Type),
+		Email:            stripeAccount.Email,
+		Country:          stripeAccount.Country,
+		BusinessType:     string(stripeAccount.BusinessType),
+		ChargesEnabled:   stripeAccount.ChargesEnabled,
+		PayoutsEnabled:   stripeAccount.PayoutsEnabled,
+		DetailsSubmitted: stripeAccount.DetailsSubmitted,
+		Metadata:         stripeAccount.Metadata,
+		Created:          stripeAccount.Created,
+	}
+
+	if stripeAccount.Requirements != nil {
+		connected.CurrentlyDue = stripeAccount.Requirements.CurrentlyDue
+		connected.DisabledReason = string(stripeAccount.Requirements.DisabledReason)
+	}
+
+	return connected
+}
+
+// ConvertTransfer converts a Stripe transfer to our Transfer type
+func ConvertTransfer(stripeTransfer *stripe.Transfer) *Transfer {
+	t := &Transfer{
+		ID:             stripeTransfer.ID,
+		Amount:         stripeTransfer.Amount,
+		AmountDisplay:  money.Describe(stripeTransfer.Amount, string(stripeTransfer.Currency)),
+		AmountReversed: stripeTransfer.AmountReversed,
+		Currency:       string(stripeTransfer.Currency),
+		TransferGroup:  stripeTransfer.TransferGroup,
+		Description:    stripeTransfer.Description,
+		Reversed:       stripeTransfer.Reversed,
+		Metadata:       stripeTransfer.Metadata,
+		Created:        stripeTransfer.Created,
+	}
+
+	if stripeTransfer.Destination != nil {
+		t.Destination = stripeTransfer.Destination.ID
+	}
+	if stripeTransfer.SourceTransaction != nil {
+		t.SourceTransaction = stripeTransfer.SourceTransaction.ID
+	}
+
+	return t
+}
@@ -0,0 +1,67 @@
+package stripe
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/customer"
+	"github.com/stripe/stripe-go/v76/customerbalancetransaction"
+)
+
+// CustomerBalance is a customer's Stripe balance, applied to their next
+// invoices. Stripe holds it in the customer's currency, which is empty until
+// the customer is first billed, and counts credit as negative.
+type CustomerBalance struct {
+	Balance  int64  `json:"balance"`
+	Currency string `json:"currency,omitempty"`
+}
+
+// GetCustomerBalance retrieves a customer's Stripe balance
+func (s *CustomerService) GetCustomerBalance(ctx context.Context, customerID string) (*CustomerBalance, error) {
+	ctx, span := s.tracer.Start(ctx, "GetCustomerBalance")
+	defer span.End()
+
+	if customerID == "" {
+		return nil, fmt.Errorf("customer ID cannot be empty")
+	}
+
+	params := &stripe.CustomerParams{}
+	params.Context = ctx
+	stripeCustomer, err := customer.Get(customerID, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve customer: %w", err)
+	}
+
+	return &CustomerBalance{
+		Balance:  stripeCustomer.Balance,
+		Currency: string(stripeCustomer.Currency),
+	}, nil
+}
+
+// AdjustCustomerBalance adds to a customer's Stripe balance, returning the
+// balance transaction's ID. Negative amounts credit the customer.
+func (s *CustomerService) AdjustCustomerBalance(ctx context.Context, customerID string, amount int64, currency, description string) (string, error) {
+	ctx, span := s.tracer.Start(ctx, "AdjustCustomerBalance")
+	defer span.End()
+
+	if customerID == "" {
+		return "", fmt.Errorf("customer ID cannot be empty")
+	}
+
+	params := &stripe.CustomerBalanceTransactionParams{
+		Customer: stripe.String(customerID),
+		Amount:   stripe.Int64(amount),
+		Currency: stripe.String(currency),
+	}
+	if description != "" {
+		params.Description = stripe.String(description)
+	}
+	params.Context = ctx
+	transaction, err := customerbalancetransaction.New(params)
+	if err != nil {
+		return "", fmt.Errorf("failed to adjust customer balance: %w", err)
+	}
+
+	return transaction.ID, nil
+}
@@ -18,6 +18,7 @@ import (
 type CustomerService struct {
 	validator *validator.Validate
 	tracer    trace.Tracer
+	mirror    Mirror
 }
 
 // NewCustomerService creates a new customer service
@@ -25,6 +26,7 @@ func NewCustomerService() *CustomerService {
 	return &CustomerService{
 		validator: validator.New(),
 		tracer:    otel.Tracer("payments.customer"),
+		mirror:    noMirror{},
 	}
 }
 
@@ -34,7 +36,10 @@ type CustomerRequest struct {
 	Name        string            `json:"name" validate:"required,min=1"`
 	Phone       string            `json:"phone,omitempty"`
 	Description string            `json:"description,omitempty"`
+	Address     *Address          `json:"address,omitempty"`
 	Metadata    map[string]string `json:"metadata,omitempty"`
+
+	IdempotencyKey string `json:"-"` // Set by callers that may create the same customer concurrently, such as upserts
 }
 
 // Customer represents a Stripe customer
@@ -44,17 +49,36 @@ type Customer struct {
 	Name        string            `json:"name"`
 	Phone       string            `json:"phone,omitempty"`
 	Description string            `json:"description,omitempty"`
+	Address     *Address          `json:"address,omitempty"`
 	Metadata    map[string]string `json:"metadata,omitempty"`
 	Created     int64             `json:"created"`
 	Updated     int64             `json:"updated"`
+
+	// DefaultPaymentMethod is charged for the customer's invoices and
+	// subscriptions
+	DefaultPaymentMethod string `json:"default_payment_method,omitempty"`
 }
 
-// PaymentMethodRequest represents a request to add a payment method
+// Address represents a customer's postal address
+type Address struct {
+	Line1      string `json:"line1,omitempty"`
+	Line2      string `json:"line2,omitempty"`
+	City       string `json:"city,omitempty"`
+	State      string `json:"state,omitempty"`
+	PostalCode string `json:"postal_code,omitempty"`
+	Country    string `json:"country,omitempty"`
+}
+
+// PaymentMethodRequest represents a request to add a payment method. Bank
+// debits (us_bank_account, sepa_debit and bacs_debit) take the bank account
+// and the customer's acceptance of the debit mandate instead of a card.
 type PaymentMethodRequest struct {
-	Type     string            `json:"type" validate:"required,oneof=card sepa_debit ideal sofort"`
-	Card     *CardRequest      `json:"card,omitempty"`
-	Customer string            `json:"customer" validate:"required"`
-	Metadata map[string]string `json:"metadata,omitempty"`
+	Type        string              `json:"type" validate:"required,oneof=card sepa_debit ideal sofort us_bank_account bacs_debit"`
+	Card        *CardRequest        `json:"card,omitempty"`
+	BankAccount *BankAccountRequest `json:"bank_account,omitempty"`
+	Mandate     *MandateAcceptance  `json:"mandate,omitempty"`
+	Customer    string              `json:"customer" validate:"required"`
+	Metadata    map[string]string   `json:"metadata,omitempty"`
 }
 
 // CardRequest represents card-specific payment method details
@@ -64,12 +88,19 @@ type CardRequest struct {
 
 // PaymentMethod represents a Stripe payment method
 type PaymentMethod struct {
-	ID       string            `json:"id"`
-	Type     string            `json:"type"`
-	Card     *Card             `json:"card,omitempty"`
-	Customer string            `json:"customer"`
-	Metadata map[string]string `json:"metadata,omitempty"`
-	Created  int64             `json:"created"`
+	ID          string            `json:"id"`
+	Type        string            `json:"type"`
+	Card        *Card             `json:"card,omitempty"`
+	BankAccount *BankAccount      `json:"bank_account,omitempty"`
+	Wallet      string            `json:"wallet,omitempty"` // Wallet the card was added with, e.g. apple_pay
+	Customer    string            `json:"customer"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	Created     int64             `json:"created"`
+
+	// SetupIntent saves a bank debit added with AddPaymentMethod. It is
+	// processing or requires micro-deposit verification until the bank
+	// account can be debited.
+	SetupIntent *SetupIntent `json:"setup_intent,omitempty"`
 }
 
 // Card represents card details
@@ -97,8 +128,13 @@ func (s *CustomerService) CreateCustomer(ctx context.Context, request *CustomerR
 		Name:        stripe.String(request.Name),
 		Phone:       stripe.String(request.Phone),
 		Description: stripe.String(request.Description),
+		Address:     addressParams(request.Address),
 		Metadata:    request.Metadata,
 	}
+	if request.IdempotencyKey != "" {
+		params.SetIdempotencyKey(request.IdempotencyKey)
+	}
+	params.Context = ctx
 
 	// Create the customer
 	stripeCustomer, err := customer.New(params)
@@ -113,10 +149,12 @@ func (s *CustomerService) CreateCustomer(ctx context.Context, request *CustomerR
 		Name:        stripeCustomer.Name,
 		Phone:       stripeCustomer.Phone,
 		Description: stripeCustomer.Description,
+		Address:     convertAddress(stripeCustomer.Address),
 		Metadata:    stripeCustomer.Metadata,
 		Created:     stripeCustomer.Created,
 		Updated:     stripeCustomer.Created, // Stripe doesn't provide updated timestamp
 	}
+	s.mirror.SaveCustomer(ctx, customer)
 
 	return customer, nil
 }
@@ -131,23 +169,13 @@ func (s *CustomerService) GetCustomer(ctx context.Context, customerID string) (*
 	}
 
 	params := &stripe.CustomerParams{}
+	params.Context = ctx
 	stripeCustomer, err := customer.Get(customerID, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve customer: %w", err)
 	}
 
-	customer := &Customer{
-		ID:          stripeCustomer.ID,
-		Email:       stripeCustomer.Email,
-		Name:        stripeCustomer.Name,
-		Phone:       stripeCustomer.Phone,
-		Description: stripeCustomer.Description,
-		Metadata:    stripeCustomer.Metadata,
-		Created:     stripeCustomer.Created,
-		Updated:     stripeCustomer.Created,
-	}
-
-	return customer, nil
+	return ConvertCustomer(stripeCustomer), nil
 }
 
 // UpdateCustomer updates an existing customer
@@ -170,8 +198,10 @@ func (s *CustomerService) UpdateCustomer(ctx context.Context, customerID string,
 		Name:        stripe.String(request.Name),
 		Phone:       stripe.String(request.Phone),
 		Description: stripe.String(request.Description),
+		Address:     addressParams(request.Address),
 		Metadata:    request.Metadata,
 	}
+	params.Context = ctx
 
 	// Update the customer
 	stripeCustomer, err := customer.Update(customerID, params)
@@ -186,10 +216,15 @@ func (s *CustomerService) UpdateCustomer(ctx context.Context, customerID string,
 		Name:        stripeCustomer.Name,
 		Phone:       stripeCustomer.Phone,
 		Description: stripeCustomer.Description,
+		Address:     convertAddress(stripeCustomer.Address),
 		Metadata:    stripeCustomer.Metadata,
 		Created:     stripeCustomer.Created,
 		Updated:     time.Now().Unix(),
 	}
+	if stripeCustomer.InvoiceSettings != nil && stripeCustomer.InvoiceSettings.DefaultPaymentMethod != nil {
+		customer.DefaultPaymentMethod = stripeCustomer.InvoiceSettings.DefaultPaymentMethod.ID
+	}
+	s.mirror.SaveCustomer(ctx, customer)
 
 	return customer, nil
 }
@@ -204,32 +239,68 @@ func (s *CustomerService) DeleteCustomer(ctx context.Context, customerID string)
 	}
 
 	params := &stripe.CustomerParams{}
+	params.Context = ctx
 	_, err := customer.Del(customerID, params)
 	if err != nil {
 		return fmt.Errorf("failed to delete Stripe customer: %w", err)
 	}
+	s.mirror.RemoveCustomer(ctx, customerID)
 
 	return nil
 }
 
+// ListCustomers lists a page of customers, newest first, optionally only
+// those with an email address
+func (s *CustomerService) ListCustomers(ctx context.Context, email string, page Page) ([]*Customer, error) {
+	ctx, span := s.tracer.Start(ctx, "ListCustomers")
+	defer span.End()
+
+	params := &stripe.CustomerListParams{}
+	if email != "" {
+		params.Email = stripe.String(email)
+	}
+	page.apply(&params.ListParams)
+	params.Context = ctx
+
+	iter := customer.List(params)
+	var customers []*Customer
+
+	for !page.full(len(customers)) && iter.Next() {
+		customers = append(customers, ConvertCustomer(iter.Customer()))
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list customers: %w", err)
+	}
+
+	return customers, nil
+}
+
 // AddPaymentMethod adds a payment method to a customer
 func (s *CustomerService) AddPaymentMethod(ctx context.Context, request *PaymentMethodRequest) (*PaymentMethod, error) {
 	ctx, span := s.tracer.Start(ctx, "AddPaymentMethod")
 	defer span.End()
 
 	// Validate the request
-	if err := s.validator.Struct(request); err != nil {
-		return nil, fmt.Errorf("validation failed: %w", err)
+	if err := s.ValidatePaymentMethodRequest(request); err != nil {
+		return nil, err
+	}
+
+	if IsBankDebit(request.Type) {
+		return s.addBankDebit(ctx, request)
 	}
 
 	// Convert to Stripe payment method params
 	params := &stripe.PaymentMethodParams{
-		Type: stripe.String(request.Type),
-		Card: &stripe.PaymentMethodCardParams{
-			Token: stripe.String(request.Card.Token),
-		},
+		Type:     stripe.String(request.Type),
 		Metadata: request.Metadata,
 	}
+	if request.Card != nil {
+		params.Card = &stripe.PaymentMethodCardParams{
+			Token: stripe.String(request.Card.Token),
+		}
+	}
+	params.Context = ctx
 
 	// Create the payment method
 	stripePaymentMethod, err := paymentmethod.New(params)
@@ -239,6 +310,7 @@ func (s *CustomerService) AddPaymentMethod(ctx context.Context, request *Payment
 
 	// Attach to customer
 	attachParams := &stripe.PaymentMethodAttachParams{
+		Params:   stripe.Params{Context: ctx},
 		Customer: stripe.String(request.Customer),
 	}
 	_, err = paymentmethod.Attach(stripePaymentMethod.ID, attachParams)
@@ -265,6 +337,7 @@ func (s *CustomerService) AddPaymentMethod(ctx context.Context, request *Payment
 			Fingerprint: stripePaymentMethod.Card.Fingerprint,
 		}
 	}
+	s.mirror.SavePaymentMethod(ctx, paymentMethod)
 
 	return paymentMethod, nil
 }
@@ -279,36 +352,18 @@ func (s *CustomerService) GetPaymentMethod(ctx context.Context, paymentMethodID
 	}
 
 	params := &stripe.PaymentMethodParams{}
+	params.Context = ctx
 	stripePaymentMethod, err := paymentmethod.Get(paymentMethodID, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve payment method: %w", err)
 	}
 
-	// Convert to our PaymentMethod type
-	paymentMethod := &PaymentMethod{
-		ID:       stripePaymentMethod.ID,
-		Type:     string(stripePaymentMethod.Type),
-		Customer: stripePaymentMethod.Customer.ID,
-		Metadata: stripePaymentMethod.Metadata,
-		Created:  stripePaymentMethod.Created,
-	}
-
-	// Add card details if available
-	if stripePaymentMethod.Card != nil {
-		paymentMethod.Card = &Card{
-			Last4:       stripePaymentMethod.Card.Last4,
-			Brand:       string(stripePaymentMethod.Card.Brand),
-			ExpMonth:    int(stripePaymentMethod.Card.ExpMonth),
-			ExpYear:     int(stripePaymentMethod.Card.ExpYear),
-			Fingerprint: stripePaymentMethod.Card.Fingerprint,
-		}
-	}
-
-	return paymentMethod, nil
+	return ConvertPaymentMethod(stripePaymentMethod), nil
 }
 
-// ListPaymentMethods retrieves payment methods for a customer
-func (s *CustomerService) ListPaymentMethods(ctx context.Context, customerID string, limit int64) ([]*PaymentMethod, error) {
+// ListPaymentMethods retrieves a page of a customer's payment methods of
+// every type, newest first
+func (s *CustomerService) ListPaymentMethods(ctx context.Context, customerID string, page Page) ([]*PaymentMethod, error) {
 	ctx, span := s.tracer.Start(ctx, "ListPaymentMethods")
 	defer span.End()
 
@@ -318,38 +373,16 @@ func (s *CustomerService) ListPaymentMethods(ctx context.Context, customerID str
 
 	params := &stripe.PaymentMethodListParams{
 		Customer: stripe.String(customerID),
-		Type:     stripe.String("card"),
 	}
 
-	if limit > 0 {
-		params.Limit = stripe.Int64(limit)
-	}
+	page.apply(&params.ListParams)
+	params.Context = ctx
 
 	iter := paymentmethod.List(params)
 	var paymentMethods []*PaymentMethod
 
-	for iter.Next() {
-		stripePaymentMethod := iter.PaymentMethod()
-		paymentMethod := &PaymentMethod{
-			ID:       stripePaymentMethod.ID,
-			Type:     string(stripePaymentMethod.Type),
-			Customer: stripePaymentMethod.Customer.ID,
-			Metadata: stripePaymentMethod.Metadata,
-			Created:  stripePaymentMethod.Created,
-		}
-
-		// Add card details if available
-		if stripePaymentMethod.Card != nil {
-			paymentMethod.Card = &Card{
-				Last4:       stripePaymentMethod.Card.Last4,
-				Brand:       string(stripePaymentMethod.Card.Brand),
-				ExpMonth:    int(stripePaymentMethod.Card.ExpMonth),
-				ExpYear:     int(stripePaymentMethod.Card.ExpYear),
-				Fingerprint: stripePaymentMethod.Card.Fingerprint,
-			}
-		}
-
-		paymentMethods = append(paymentMethods, paymentMethod)
+	for !page.full(len(paymentMethods)) && iter.Next() {
+		paymentMethods = append(paymentMethods, ConvertPaymentMethod(iter.PaymentMethod()))
 	}
 
 	if err := iter.Err(); err != nil {
@@ -368,14 +401,119 @@ func (s *CustomerService) DetachPaymentMethod(ctx context.Context, paymentMethod
 		return fmt.Errorf("payment method ID cannot be empty")
 	}
 
-	_, err := paymentmethod.Detach(paymentMethodID, &stripe.PaymentMethodDetachParams{})
+	_, err := paymentmethod.Detach(paymentMethodID, &stripe.PaymentMethodDetachParams{Params: stripe.Params{Context: ctx}})
 	if err != nil {
 		return fmt.Errorf("failed to detach payment method: %w", err)
 	}
+	s.mirror.RemovePaymentMethod(ctx, paymentMethodID)
 
 	return nil
 }
 
+// SetDefaultPaymentMethod makes a payment method the one the customer's
+// invoices and subscriptions are charged to
+func (s *CustomerService) SetDefaultPaymentMethod(ctx context.Context, customerID, paymentMethodID string) error {
+	ctx, span := s.tracer.Start(ctx, "SetDefaultPaymentMethod")
+	defer span.End()
+
+	if customerID == "" {
+		return fmt.Errorf("customer ID cannot be empty")
+	}
+
+	params := &stripe.CustomerParams{
+		InvoiceSettings: &stripe.CustomerInvoiceSettingsParams{
+			DefaultPaymentMethod: stripe.String(paymentMethodID),
+		},
+	}
+	params.Context = ctx
+	if _, err := customer.Update(customerID, params); err != nil {
+		return fmt.Errorf("failed to set default payment method: %w", err)
+	}
+
+	return nil
+}
+
+// ConvertCustomer converts a Stripe customer to our Customer type
+func ConvertCustomer(stripeCustomer *stripe.Customer) *Customer {
+	customer := &Customer{
+		ID:          stripeCustomer.ID,
+		Email:       stripeCustomer.Email,
+		Name:        stripeCustomer.Name,
+		Phone:       stripeCustomer.Phone,
+		Description: stripeCustomer.Description,
+		Address:     convertAddress(stripeCustomer.Address),
+		Metadata:    stripeCustomer.Metadata,
+		Created:     stripeCustomer.Created,
+		Updated:     stripeCustomer.Created, // Stripe doesn't provide updated timestamp
+	}
+	if stripeCustomer.InvoiceSettings != nil && stripeCustomer.InvoiceSettings.DefaultPaymentMethod != nil {
+		customer.DefaultPaymentMethod = stripeCustomer.InvoiceSettings.DefaultPaymentMethod.ID
+	}
+	return customer
+}
+
+// addressParams converts an address to Stripe address params
+func addressParams(address *Address) *stripe.AddressParams {
+	if address == nil {
+		return nil
+	}
+
+	return &stripe.AddressParams{
+		Line1:      stripe.String(address.Line1),
+		Line2:      stripe.String(address.Line2),
+		City:       stripe.String(address.City),
+		State:      stripe.String(address.State),
+		PostalCode: stripe.String(address.PostalCode),
+		Country:    stripe.String(address.Country),
+	}
+}
+
+// convertAddress converts a Stripe address to our Address type
+func convertAddress(stripeAddress *stripe.Address) *Address {
+	if stripeAddress == nil {
+		return nil
+	}
+
+	return &Address{
+		Line1:      stripeAddress.Line1,
+		Line2:      stripeAddress.Line2,
+		City:       stripeAddress.City,
+		State:      stripeAddress.State,
+		PostalCode: stripeAddress.PostalCode,
+		Country:    stripeAddress.Country,
+	}
+}
+
+// ConvertPaymentMethod converts a Stripe payment method to our PaymentMethod type
+func ConvertPaymentMethod(stripePaymentMethod *stripe.PaymentMethod) *PaymentMethod {
+	paymentMethod := &PaymentMethod{
+		ID:       stripePaymentMethod.ID,
+		Type:     string(stripePaymentMethod.Type),
+		Metadata: stripePaymentMethod.Metadata,
+		Created:  stripePaymentMethod.Created,
+	}
+
+	// Detached payment methods have no customer
+	if stripePaymentMethod.Customer != nil {
+		paymentMethod.Customer = stripePaymentMethod.Customer.ID
+	}
+
+	// Add card details if available
+	if stripePaymentMethod.Card != nil {
+		paymentMethod.Card = &Card{
+			Last4:       stripePaymentMethod.Card.Last4,
+			Brand:       string(stripePaymentMethod.Card.Brand),
+			ExpMonth:    int(stripePaymentMethod.Card.ExpMonth),
+			ExpYear:     int(stripePaymentMethod.Card.ExpYear),
+			Fingerprint: stripePaymentMethod.Card.Fingerprint,
+		}
+		paymentMethod.Wallet = cardWallet(stripePaymentMethod.Card)
+	}
+	paymentMethod.BankAccount = convertBankAccount(stripePaymentMethod)
+
+	return paymentMethod
+}
+
 // ValidateCustomerRequest validates a customer request
 func (s *CustomerService) ValidateCustomerRequest(request *CustomerRequest) error {
 	if err := s.validator.Struct(request); err != nil {
@@ -415,6 +553,14 @@ func (s *CustomerService) ValidatePaymentMethodRequest(request *PaymentMethodReq
 		return fmt.Errorf("card token is required")
 	}
 
+	if IsBankDebit(request.Type) && request.BankAccount == nil {
+		return ErrBankAccountRequired
+	}
+
+	if IsBankDebit(request.Type) && request.Mandate == nil {
+		return ErrMandateRequired
+	}
+
 	return nil
 }
 
@@ -0,0 +1,307 @@
+package stripe
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"apis/payments/services/money"
+
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/dispute"
+	"github.com/stripe/stripe-go/v76/file"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DisputeService handles Stripe dispute operations
+type DisputeService struct {
+	tracer trace.Tracer
+}
+
+// NewDisputeService creates a new dispute service
+func NewDisputeService() *DisputeService {
+	return &DisputeService{
+		tracer: otel.Tracer("payments.dispute"),
+	}
+}
+
+// Dispute represents a Stripe dispute
+type Dispute struct {
+	ID                   string                       `json:"id"`
+	ChargeID             string                       `json:"charge_id"`
+	PaymentIntentID      string                       `json:"payment_intent_id,omitempty"`
+	Amount               int64                        `json:"amount"`
+	AmountDisplay        money.Display                `json:"amount_display"`
+	Currency             string                       `json:"currency"`
+	Reason               string                       `json:"reason"`
+	Status               string                       `json:"status"`
+	NetworkReasonCode    string                       `json:"network_reason_code,omitempty"`
+	IsChargeRefundable   bool                         `json:"is_charge_refundable"`
+	Evidence             *DisputeEvidence             `json:"evidence"`
+	EvidenceDetails      *DisputeEvidenceDetails      `json:"evidence_details"`
+	PaymentMethodDetails *DisputePaymentMethodDetails `json:"payment_method_details,omitempty"`
+	BalanceTransactions  []string                     `json:"balance_transactions,omitempty"`
+	Livemode             bool                         `json:"livemode"`
+	Metadata             map[string]string            `json:"metadata,omitempty"`
+	Created              int64                        `json:"created"`
+}
+
+// DisputeEvidence is the evidence staged or submitted for a dispute. File
+// fields hold Stripe file IDs.
+type DisputeEvidence struct {
+	AccessActivityLog            string `json:"access_activity_log,omitempty"`
+	BillingAddress               string `json:"billing_address,omitempty"`
+	CancellationPolicy           string `json:"cancellation_policy,omitempty"`
+	CancellationPolicyDisclosure string `json:"cancellation_policy_disclosure,omitempty"`
+	CancellationRebuttal         string `json:"cancellation_rebuttal,omitempty"`
+	CustomerCommunication        string `json:"customer_communication,omitempty"`
+	CustomerEmailAddress         string `json:"customer_email_address,omitempty"`
+	CustomerName                 string `json:"customer_name,omitempty"`
+	CustomerPurchaseIP           string `json:"customer_purchase_ip,omitempty"`
+	CustomerSignature            string `json:"customer_signature,omitempty"`
+	DuplicateChargeDocumentation string `json:"duplicate_charge_documentation,omitempty"`
+	DuplicateChargeExplanation   string `json:"duplicate_charge_explanation,omitempty"`
+	DuplicateChargeID            string `json:"duplicate_charge_id,omitempty"`
+	ProductDescription           string `json:"product_description,omitempty"`
+	Receipt                      string `json:"receipt,omitempty"`
+	RefundPolicy                 string `json:"refund_policy,omitempty"`
+	RefundPolicyDisclosure       string `json:"refund_policy_disclosure,omitempty"`
+	RefundRefusalExplanation     string `json:"refund_refusal_explanation,omitempty"`
+	ServiceDate                  string `json:"service_date,omitempty"`
+	ServiceDocumentation         string `json:"service_documentation,omitempty"`
+	ShippingAddress              string `json:"shipping_address,omitempty"`
+	ShippingCarrier              string `json:"shipping_carrier,omitempty"`
+	ShippingDate                 string `json:"shipping_date,omitempty"`
+	ShippingDocumentation        string `json:"shipping_documentation,omitempty"`
+	ShippingTrackingNumber       string `json:"shipping_tracking_number,omitempty"`
+	UncategorizedFile            string `json:"uncategorized_file,omitempty"`
+	UncategorizedText            string `json:"uncategorized_text,omitempty"`
+}
+
+// DisputeEvidenceDetails describes the evidence deadline and submissions
+type DisputeEvidenceDetails struct {
+	DueBy           *time.Time `json:"due_by,omitempty"` // Nil when the issuer allows no response
+	HasEvidence     bool       `json:"has_evidence"`
+	PastDue         bool       `json:"past_due"`
+	SubmissionCount int64      `json:"submission_count"`
+}
+
+// DisputePaymentMethodDetails holds network details of the disputed payment
+type DisputePaymentMethodDetails struct {
+	Type                  string `json:"type"`
+	CardBrand             string `json:"card_brand,omitempty"`
+	CardNetworkReasonCode string `json:"card_network_reason_code,omitempty"`
+}
+
+// GetDispute retrieves a dispute from Stripe
+func (s *DisputeService) GetDispute(ctx context.Context, disputeID string) (*Dispute, error) {
+	ctx, span := s.tracer.Start(ctx, "GetDispute")
+	defer span.End()
+
+	if disputeID == "" {
+		return nil, fmt.Errorf("dispute ID cannot be empty")
+	}
+
+	stripeDispute, err := dispute.Get(disputeID, &stripe.DisputeParams{Params: stripe.Params{Context: ctx}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve dispute: %w", err)
+	}
+
+	return ConvertDispute(stripeDispute), nil
+}
+
+// ListDisputes lists a page of disputes, newest first, optionally of one charge
+func (s *DisputeService) ListDisputes(ctx context.Context, chargeID string, page Page) ([]*Dispute, error) {
+	ctx, span := s.tracer.Start(ctx, "ListDisputes")
+	defer span.End()
+
+	params := &stripe.DisputeListParams{}
+	if chargeID != "" {
+		params.Charge = stripe.String(chargeID)
+	}
+	page.apply(&params.ListParams)
+
+	params.Context = ctx
+	iter := dispute.List(params)
+	var disputes []*Dispute
+
+	for !page.full(len(disputes)) && iter.Next() {
+		disputes = append(disputes, ConvertDispute(iter.Dispute()))
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list disputes: %w", err)
+	}
+
+	return disputes, nil
+}
+
+// CloseDispute concedes a dispute to the cardholder
+func (s *DisputeService) CloseDispute(ctx context.Context, disputeID string) (*Dispute, error) {
+	ctx, span := s.tracer.Start(ctx, "CloseDispute")
+	defer span.End()
+
+	if disputeID == "" {
+		return nil, fmt.Errorf("dispute ID cannot be empty")
+	}
+
+	stripeDispute, err := dispute.Close(disputeID, &stripe.DisputeParams{Params: stripe.Params{Context: ctx}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to close dispute: %w", err)
+	}
+
+	return ConvertDispute(stripeDispute), nil
+}
+
+// UpdateDisputeEvidence stages evidence on a dispute, keyed by Stripe's
+// evidence field names, and submits it to the bank when submit is set
+func (s *DisputeService) UpdateDisputeEvidence(ctx context.Context, disputeID string, evidence map[string]string, submit bool) (*Dispute, error) {
+	ctx, span := s.tracer.Start(ctx, "UpdateDisputeEvidence")
+	defer span.End()
+
+	if disputeID == "" {
+		return nil, fmt.Errorf("dispute ID cannot be empty")
+	}
+
+	params := &stripe.DisputeParams{Submit: stripe.Bool(submit)}
+	for field, value := range evidence {
+		params.AddExtra("evidence["+field+"]", value)
+	}
+
+	params.Context = ctx
+	stripeDispute, err := dispute.Update(disputeID, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update dispute evidence: %w", err)
+	}
+
+	return ConvertDispute(stripeDispute), nil
+}
+
+// DisputeFile is a file uploaded to Stripe as dispute evidence
+type DisputeFile struct {
+	ID       string `json:"id"`
+	Filename string `json:"filename"`
+	Size     int64  `json:"size"`
+	Type     string `json:"type"`
+	Created  int64  `json:"created"`
+}
+
+// UploadDisputeEvidenceFile uploads a file for use as dispute evidence. The
+// returned file ID is set on the dispute's file evidence fields.
+func (s *DisputeService) UploadDisputeEvidenceFile(ctx context.Context, filename string, content io.Reader) (*DisputeFile, error) {
+	ctx, span := s.tracer.Start(ctx, "UploadDisputeEvidenceFile")
+	defer span.End()
+
+	params := &stripe.FileParams{
+		FileReader: content,
+		Filename:   stripe.String(filename),
+		Purpose:    stripe.String(string(stripe.FilePurposeDisputeEvidence)),
+	}
+
+	params.Context = ctx
+	stripeFile, err := file.New(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload dispute evidence file: %w", err)
+	}
+
+	return &DisputeFile{
+		ID:       stripeFile.ID,
+		Filename: stripeFile.Filename,
+		Size:     stripeFile.Size,
+		Type:     stripeFile.Type,
+		Created:  stripeFile.Created,
+	}, nil
+}
+
+// ConvertDispute converts a Stripe dispute to our Dispute type, keeping every
+// field Stripe reports
+func ConvertDispute(stripeDispute *stripe.Dispute) *Dispute {
+	d := &Dispute{
+		ID:                 stripeDispute.ID,
+		Amount:             stripeDispute.Amount,
+		AmountDisplay:      money.Describe(stripeDispute.Amount, string(stripeDispute.Currency)),
+		Currency:           string(stripeDispute.Currency),
+		Reason:             string(stripeDispute.Reason),
+		Status:             string(stripeDispute.Status),
+		NetworkReasonCode:  stripeDispute.NetworkReasonCode,
+		IsChargeRefundable: stripeDispute.IsChargeRefundable,
+		Evidence:           &DisputeEvidence{},
+		EvidenceDetails:    &DisputeEvidenceDetails{},
+		Livemode:           stripeDispute.Livemode,
+		Metadata:           stripeDispute.Metadata,
+		Created:            stripeDispute.Created,
+	}
+
+	if stripeDispute.Charge != nil {
+		d.ChargeID = stripeDispute.Charge.ID
+	}
+	if stripeDispute.PaymentIntent != nil {
+		d.PaymentIntentID = stripeDispute.PaymentIntent.ID
+	}
+	if evidence := stripeDispute.Evidence; evidence != nil {
+		d.Evidence = &DisputeEvidence{
+			AccessActivityLog:            evidence.AccessActivityLog,
+			BillingAddress:               evidence.BillingAddress,
+			CancellationPolicy:           fileID(evidence.CancellationPolicy),
+			CancellationPolicyDisclosure: evidence.CancellationPolicyDisclosure,
+			CancellationRebuttal:         evidence.CancellationRebuttal,
+			CustomerCommunication:        fileID(evidence.CustomerCommunication),
+			CustomerEmailAddress:         evidence.CustomerEmailAddress,
+			CustomerName:                 evidence.CustomerName,
+			CustomerPurchaseIP:           evidence.CustomerPurchaseIP,
+			CustomerSignature:            fileID(evidence.CustomerSignature),
+			DuplicateChargeDocumentation: fileID(evidence.DuplicateChargeDocumentation),
+			DuplicateChargeExplanation:   evidence.DuplicateChargeExplanation,
+			DuplicateChargeID:            evidence.DuplicateChargeID,
+			ProductDescription:           evidence.ProductDescription,
+			Receipt:                      fileID(evidence.Receipt),
+			RefundPolicy:                 fileID(evidence.RefundPolicy),
+			RefundPolicyDisclosure:       evidence.RefundPolicyDisclosure,
+			RefundRefusalExplanation:     evidence.RefundRefusalExplanation,
+			ServiceDate:                  evidence.ServiceDate,
+			ServiceDocumentation:         fileID(evidence.ServiceDocumentation),
+			ShippingAddress:              evidence.ShippingAddress,
+			ShippingCarrier:              evidence.ShippingCarrier,
+			ShippingDate:                 evidence.ShippingDate,
+			ShippingDocumentation:        fileID(evidence.ShippingDocumentation),
+			ShippingTrackingNumber:       evidence.ShippingTrackingNumber,
+			UncategorizedFile:            fileID(evidence.UncategorizedFile),
+			UncategorizedText:            evidence.UncategorizedText,
+		}
+	}
+	if details := stripeDispute.EvidenceDetails; details != nil {
+		d.EvidenceDetails = &DisputeEvidenceDetails{
+			HasEvidence:     details.HasEvidence,
+			PastDue:         details.PastDue,
+			SubmissionCount: details.SubmissionCount,
+		}
+		if details.DueBy > 0 {
+			dueBy := time.Unix(details.DueBy, 0).UTC()
+			d.EvidenceDetails.DueBy = &dueBy
+		}
+	}
+	if details := stripeDispute.PaymentMethodDetails; details != nil {
+		d.PaymentMethodDetails = &DisputePaymentMethodDetails{Type: string(details.Type)}
+		if details.Card != nil {
+			d.PaymentMethodDetails.CardBrand = details.Card.Brand
+			d.PaymentMethodDetails.CardNetworkReasonCode = details.Card.NetworkReasonCode
+		}
+	}
+	for _, transaction := range stripeDispute.BalanceTransactions {
+		if transaction != nil {
+			d.BalanceTransactions = append(d.BalanceTransactions, transaction.ID)
+		}
+	}
+
+	return d
+}
+
+// fileID returns the ID of an evidence file, or "" when none was uploaded
+func fileID(file *stripe.File) string {
+	if file == nil {
+		return ""
+	}
+	return file.ID
+}
@@ -0,0 +1,374 @@
+package stripe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/invoice"
+	"github.com/stripe/stripe-go/v76/invoiceitem"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Invoice collection methods
+const (
+	CollectionChargeAutomatically = "charge_automatically"
+	CollectionSendInvoice         = "send_invoice"
+)
+
+// ErrDaysUntilDueRequired is returned when creating a send_invoice invoice
+// without payment terms
+var ErrDaysUntilDueRequired = errors.New("days_until_due is required for send_invoice invoices")
+
+// InvoiceService handles Stripe invoice operations
+type InvoiceService struct {
+	validator *validator.Validate
+	tracer    trace.Tracer
+}
+
+// NewInvoiceService creates a new invoice service
+func NewInvoiceService() *InvoiceService {
+	return &InvoiceService{
+		validator: validator.New(),
+		tracer:    otel.Tracer("payments.invoice"),
+	}
+}
+
+// Invoice represents a Stripe invoice
+type Invoice struct {
+	ID               string               `json:"id"`
+	Number           string               `json:"number,omitempty"`
+	CustomerID       string               `json:"customer_id"`
+	CustomerName     string               `json:"customer_name,omitempty"`
+	CustomerEmail    string               `json:"customer_email,omitempty"`
+	SubscriptionID   string               `json:"subscription_id,omitempty"`
+	Status           string               `json:"status"`
+	CollectionMethod string               `json:"collection_method"`
+	Description      string               `json:"description,omitempty"`
+	AmountDue        int64                `json:"amount_due"`
+	AmountPaid       int64                `json:"amount_paid"`
+	AmountRemaining  int64                `json:"amount_remaining"`
+	Subtotal         int64                `json:"subtotal"`
+	Tax              int64                `json:"tax"` // Exclusive tax added to the subtotal
+	Total            int64                `json:"total"`
+	Currency         string               `json:"currency"`
+	Lines            []InvoiceLine        `json:"lines,omitempty"`
+	TaxAmounts       []InvoiceTaxAmount   `json:"tax_amounts,omitempty"`
+	DueDate          *time.Time           `json:"due_date,omitempty"`
+	PaidOutOfBand    bool                 `json:"paid_out_of_band"`
+	HostedInvoiceURL string               `json:"hosted_invoice_url,omitempty"`
+	InvoicePDF       string               `json:"invoice_pdf,omitempty"` // Set once the invoice is finalized
+	CustomFields     []InvoiceCustomField `json:"custom_fields,omitempty"`
+	Metadata         map[string]string    `json:"metadata,omitempty"`
+	Created          int64                `json:"created"`
+}
+
+// InvoiceLine is a line item of an invoice
+type InvoiceLine struct {
+	Description string `json:"description"`
+	Quantity    int64  `json:"quantity"`
+	UnitAmount  int64  `json:"unit_amount,omitempty"`
+	Amount      int64  `json:"amount"`
+}
+
+// InvoiceTaxAmount is the tax an invoice charges at one rate
+type InvoiceTaxAmount struct {
+	Name       string  `json:"name,omitempty"` // The tax rate's display name, e.g. "VAT"
+	Percentage float64 `json:"percentage,omitempty"`
+	Inclusive  bool    `json:"inclusive"` // Already included in the line amounts
+	Amount     int64   `json:"amount"`
+}
+
+// InvoiceCustomField is a name and value printed on an invoice
+type InvoiceCustomField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// CreateInvoiceRequest creates a draft invoice for a customer from line items
+type CreateInvoiceRequest struct {
+	CustomerID       string               `json:"customer_id" validate:"required"`
+	Currency         string               `json:"currency" validate:"required,len=3"`
+	Description      string               `json:"description,omitempty"`
+	CollectionMethod string               `json:"collection_method,omitempty" validate:"omitempty,oneof=charge_automatically send_invoice"`
+	DaysUntilDue     int64                `json:"days_until_due,omitempty" validate:"gte=0"` // Required for send_invoice
+	Items            []InvoiceItemRequest `json:"items" validate:"required,min=1,dive"`
+	Metadata         map[string]string    `json:"metadata,omitempty"`
+}
+
+// InvoiceItemRequest is a line item of a new invoice
+type InvoiceItemRequest struct {
+	Amount      int64  `json:"amount" validate:"required,gt=0"`
+	Description string `json:"description" validate:"required"`
+}
+
+// ValidateInvoice validates an invoice request
+func (s *InvoiceService) ValidateInvoice(request *CreateInvoiceRequest) error {
+	if err := s.validator.Struct(request); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+	if request.CollectionMethod == CollectionSendInvoice && request.DaysUntilDue == 0 {
+		return ErrDaysUntilDueRequired
+	}
+	return nil
+}
+
+// CreateInvoice creates a draft invoice holding only the requested line
+// items, leaving other pending invoice items for the customer's next
+// invoice. Drafts are not finalized automatically.
+func (s *InvoiceService) CreateInvoice(ctx context.Context, request *CreateInvoiceRequest) (*Invoice, error) {
+	ctx, span := s.tracer.Start(ctx, "CreateInvoice")
+	defer span.End()
+
+	if err := s.ValidateInvoice(request); err != nil {
+		return nil, err
+	}
+
+	params := &stripe.InvoiceParams{
+		Customer:                    stripe.String(request.CustomerID),
+		Currency:                    stripe.String(request.Currency),
+		AutoAdvance:                 stripe.Bool(false),
+		PendingInvoiceItemsBehavior: stripe.String("exclude"),
+	}
+	if request.Description != "" {
+		params.Description = stripe.String(request.Description)
+	}
+	if request.CollectionMethod != "" {
+		params.CollectionMethod = stripe.String(request.CollectionMethod)
+	}
+	if request.CollectionMethod == CollectionSendInvoice {
+		params.DaysUntilDue = stripe.Int64(request.DaysUntilDue)
+	}
+	if len(request.Metadata) > 0 {
+		params.Metadata = request.Metadata
+	}
+
+	params.Context = ctx
+	draft, err := invoice.New(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create invoice: %w", err)
+	}
+
+	for _, item := range request.Items {
+		_, err := invoiceitem.New(&stripe.InvoiceItemParams{
+			Params:      stripe.Params{Context: ctx},
+			Customer:    stripe.String(request.CustomerID),
+			Invoice:     stripe.String(draft.ID),
+			Amount:      stripe.Int64(item.Amount),
+			Currency:    stripe.String(request.Currency),
+			Description: stripe.String(item.Description),
+		})
+		if err != nil {
+			// Don't leave a partial draft behind
+			if _, delErr := invoice.Del(draft.ID, &stripe.InvoiceParams{Params: stripe.Params{Context: ctx}}); delErr != nil {
+				return nil, fmt.Errorf("failed to add invoice item: %w (and failed to delete draft %s: %v)", err, draft.ID, delErr)
+			}
+			return nil, fmt.Errorf("failed to add invoice item: %w", err)
+		}
+	}
+
+	// Re-read the draft so its totals include the items
+	stripeInvoice, err := invoice.Get(draft.ID, &stripe.InvoiceParams{Params: stripe.Params{Context: ctx}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve invoice: %w", err)
+	}
+
+	return ConvertInvoice(stripeInvoice), nil
+}
+
+// GetInvoice retrieves an invoice by ID
+func (s *InvoiceService) GetInvoice(ctx context.Context, invoiceID string) (*Invoice, error) {
+	ctx, span := s.tracer.Start(ctx, "GetInvoice")
+	defer span.End()
+
+	if invoiceID == "" {
+		return nil, fmt.Errorf("invoice ID cannot be empty")
+	}
+
+	params := &stripe.InvoiceParams{Params: stripe.Params{Context: ctx}}
+	params.AddExpand("total_tax_amounts.tax_rate")
+	stripeInvoice, err := invoice.Get(invoiceID, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve invoice: %w", err)
+	}
+
+	return ConvertInvoice(stripeInvoice), nil
+}
+
+// FinalizeInvoice finalizes a draft invoice so it can be paid. Invoices
+// collected automatically are then charged by Stripe; send_invoice invoices
+// are emailed to the customer.
+func (s *InvoiceService) FinalizeInvoice(ctx context.Context, invoiceID string) (*Invoice, error) {
+	ctx, span := s.tracer.Start(ctx, "FinalizeInvoice")
+	defer span.End()
+
+	if invoiceID == "" {
+		return nil, fmt.Errorf("invoice ID cannot be empty")
+	}
+
+	stripeInvoice, err := invoice.FinalizeInvoice(invoiceID, &stripe.InvoiceFinalizeInvoiceParams{
+		Params:      stripe.Params{Context: ctx},
+		AutoAdvance: stripe.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to finalize invoice: %w", err)
+	}
+
+	return ConvertInvoice(stripeInvoice), nil
+}
+
+// PayInvoice pays an open invoice now, with the given payment method or the
+// customer's default one
+func (s *InvoiceService) PayInvoice(ctx context.Context, invoiceID, paymentMethodID string) (*Invoice, error) {
+	ctx, span := s.tracer.Start(ctx, "PayInvoice")
+	defer span.End()
+
+	if invoiceID == "" {
+		return nil, fmt.Errorf("invoice ID cannot be empty")
+	}
+
+	params := &stripe.InvoicePayParams{}
+	if paymentMethodID != "" {
+		params.PaymentMethod = stripe.String(paymentMethodID)
+	}
+
+	params.Context = ctx
+	stripeInvoice, err := invoice.Pay(invoiceID, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pay invoice: %w", err)
+	}
+
+	return ConvertInvoice(stripeInvoice), nil
+}
+
+// MarkInvoicePaidOutOfBand marks an open invoice as paid outside Stripe,
+// e.g. by a bank transfer to the merchant's own account
+func (s *InvoiceService) MarkInvoicePaidOutOfBand(ctx context.Context, invoiceID string) (*Invoice, error) {
+	ctx, span := s.tracer.Start(ctx, "MarkInvoicePaidOutOfBand")
+	defer span.End()
+
+	if invoiceID == "" {
+		return nil, fmt.Errorf("invoice ID cannot be empty")
+	}
+
+	stripeInvoice, err := invoice.Pay(invoiceID, &stripe.InvoicePayParams{
+		Params:        stripe.Params{Context: ctx},
+		PaidOutOfBand: stripe.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark invoice as paid: %w", err)
+	}
+
+	return ConvertInvoice(stripeInvoice), nil
+}
+
+// VoidInvoice voids an open invoice. Drafts are deleted instead of voided at
+// Stripe, so only finalized invoices can be voided.
+func (s *InvoiceService) VoidInvoice(ctx context.Context, invoiceID string) (*Invoice, error) {
+	ctx, span := s.tracer.Start(ctx, "VoidInvoice")
+	defer span.End()
+
+	if invoiceID == "" {
+		return nil, fmt.Errorf("invoice ID cannot be empty")
+	}
+
+	stripeInvoice, err := invoice.VoidInvoice(invoiceID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to void invoice: %w", err)
+	}
+
+	return ConvertInvoice(stripeInvoice), nil
+}
+
+// ListInvoices lists invoices, newest first, optionally for one customer
+// and in one status
+func (s *InvoiceService) ListInvoices(ctx context.Context, customerID, status string, page Page) ([]*Invoice, error) {
+	ctx, span := s.tracer.Start(ctx, "ListInvoices")
+	defer span.End()
+
+	params := &stripe.InvoiceListParams{}
+	if customerID != "" {
+		params.Customer = stripe.String(customerID)
+	}
+	if status != "" {
+		params.Status = stripe.String(status)
+	}
+	page.apply(&params.ListParams)
+
+	params.Context = ctx
+	iter := invoice.List(params)
+	var invoices []*Invoice
+
+	for !page.full(len(invoices)) && iter.Next() {
+		invoices = append(invoices, ConvertInvoice(iter.Invoice()))
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list invoices: %w", err)
+	}
+
+	return invoices, nil
+}
+
+// ConvertInvoice converts a Stripe invoice to our Invoice type
+func ConvertInvoice(stripeInvoice *stripe.Invoice) *Invoice {
+	inv := &Invoice{
+		ID:               stripeInvoice.ID,
+		Number:           stripeInvoice.Number,
+		Status:           string(stripeInvoice.Status),
+		CollectionMethod: string(stripeInvoice.CollectionMethod),
+		Description:      stripeInvoice.Description,
+		AmountDue:        stripeInvoice.AmountDue,
+		AmountPaid:       stripeInvoice.AmountPaid,
+		AmountRemaining:  stripeInvoice.AmountRemaining,
+		Subtotal:         stripeInvoice.Subtotal,
+		Tax:              stripeInvoice.Tax,
+		Total:            stripeInvoice.Total,
+		Currency:         string(stripeInvoice.Currency),
+		CustomerName:     stripeInvoice.CustomerName,
+		CustomerEmail:    stripeInvoice.CustomerEmail,
+		PaidOutOfBand:    stripeInvoice.PaidOutOfBand,
+		HostedInvoiceURL: stripeInvoice.HostedInvoiceURL,
+		InvoicePDF:       stripeInvoice.InvoicePDF,
+		Metadata:         stripeInvoice.Metadata,
+		Created:          stripeInvoice.Created,
+	}
+
+	if stripeInvoice.Customer != nil {
+		inv.CustomerID = stripeInvoice.Customer.ID
+	}
+	if stripeInvoice.Subscription != nil {
+		inv.SubscriptionID = stripeInvoice.Subscription.ID
+	}
+	for _, field := range stripeInvoice.CustomFields {
+		inv.CustomFields = append(inv.CustomFields, InvoiceCustomField{Name: field.Name, Value: field.Value})
+	}
+	if stripeInvoice.DueDate > 0 {
+		dueDate := time.Unix(stripeInvoice.DueDate, 0).UTC()
+		inv.DueDate = &dueDate
+	}
+	if stripeInvoice.Lines != nil {
+		for _, item := range stripeInvoice.Lines.Data {
+			line := InvoiceLine{Description: item.Description, Quantity: item.Quantity, Amount: item.Amount}
+			if item.Price != nil {
+				line.UnitAmount = item.Price.UnitAmount
+			}
+			inv.Lines = append(inv.Lines, line)
+		}
+	}
+	// Tax rates are only named when expanded
+	for _, tax := range stripeInvoice.TotalTaxAmounts {
+		amount := InvoiceTaxAmount{Inclusive: tax.Inclusive, Amount: tax.Amount}
+		if tax.TaxRate != nil {
+			amount.Name = tax.TaxRate.DisplayName
+			amount.Percentage = tax.TaxRate.Percentage
+		}
+		inv.TaxAmounts = append(inv.TaxAmounts, amount)
+	}
+
+	return inv
+}
@@ -0,0 +1,47 @@
+package stripe
+
+import "context"
+
+// Mirror keeps a local copy of provider objects. Services report every object
+// they create, change or remove; a mirror handles its own failures, since the
+// provider's webhook for the same change can still repair the copy.
+type Mirror interface {
+	SaveCustomer(ctx context.Context, customer *Customer)
+	RemoveCustomer(ctx context.Context, customerID string)
+	SavePaymentMethod(ctx context.Context, paymentMethod *PaymentMethod)
+	RemovePaymentMethod(ctx context.Context, paymentMethodID string)
+	SaveCharge(ctx context.Context, charge *Charge)
+	SaveRefund(ctx context.Context, refund *Refund)
+	SaveSubscription(ctx context.Context, subscription *Subscription)
+}
+
+// noMirror discards reported objects until a mirror is configured
+type noMirror struct{}
+
+func (noMirror) SaveCustomer(ctx context.Context, customer *Customer)                {}
+func (noMirror) RemoveCustomer(ctx context.Context, customerID string)               {}
+func (noMirror) SavePaymentMethod(ctx context.Context, paymentMethod *PaymentMethod) {}
+func (noMirror) RemovePaymentMethod(ctx context.Context, paymentMethodID string)     {}
+func (noMirror) SaveCharge(ctx context.Context, charge *Charge)                      {}
+func (noMirror) SaveRefund(ctx context.Context, refund *Refund)                      {}
+func (noMirror) SaveSubscription(ctx context.Context, subscription *Subscription)    {}
+
+// UseMirror reports customers and payment methods to a mirror after every mutation
+func (s *CustomerService) UseMirror(mirror Mirror) {
+	s.mirror = mirror
+}
+
+// UseMirror reports charges to a mirror after every mutation
+func (s *ChargeService) UseMirror(mirror Mirror) {
+	s.mirror = mirror
+}
+
+// UseMirror reports refunds to a mirror after every mutation
+func (s *RefundService) UseMirror(mirror Mirror) {
+	s.mirror = mirror
+}
+
+// UseMirror reports subscriptions to a mirror after every mutation
+func (s *SubscriptionService) UseMirror(mirror Mirror) {
+	s.mirror = mirror
+}
@@ -0,0 +1,153 @@
+package stripe
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/charge"
+)
+
+// Card credential types, from cheapest to most expensive for the network
+const (
+	CredentialNetworkToken = "network_token" // Network token provisioned for the card
+	CredentialDPAN         = "dpan"          // Device PAN from a wallet such as Apple Pay
+	CredentialPAN          = "pan"           // Raw card number
+)
+
+// defaultTenantID is recorded for charges that carry no tenant metadata
+const defaultTenantID = "default"
+
+// ChargeCredential records which card credential authorized a charge
+type ChargeCredential struct {
+	ChargeID        string `json:"charge_id"`
+	TenantID        string `json:"tenant_id"`
+	CustomerID      string `json:"customer_id"`
+	PaymentMethodID string `json:"payment_method_id,omitempty"`
+	Network         string `json:"network,omitempty"`
+	CredentialType  string `json:"credential_type"`
+	Wallet          string `json:"wallet,omitempty"`
+	Amount          int64  `json:"amount"`
+	Currency        string `json:"currency"`
+	Status          string `json:"status"`
+	FailureCode     string `json:"failure_code,omitempty"`
+}
+
+// CredentialStats aggregates charges by credential type, card network and
+// wallet, so wallet volume can be told apart from card volume
+type CredentialStats struct {
+	CredentialType  string  `json:"credential_type"`
+	Network         string  `json:"network"`
+	Wallet          string  `json:"wallet,omitempty"`
+	ChargeCount     int64   `json:"charge_count"`
+	SucceededCount  int64   `json:"succeeded_count"`
+	SucceededAmount int64   `json:"succeeded_amount"`
+	ApprovalRate    float64 `json:"approval_rate"`
+}
+
+// CredentialLog persists the credential used by each charge
+type CredentialLog interface {
+	RecordChargeCredential(ctx context.Context, credential *ChargeCredential) error
+}
+
+// UseCredentialLog records the credential type of every charge created by the service
+func (s *ChargeService) UseCredentialLog(credentials CredentialLog) {
+	s.credentials = credentials
+}
+
+// recordCredential stores the credential of a charge. Failures are logged
+// rather than returned, since the charge itself has already been made.
+func (s *ChargeService) recordCredential(ctx context.Context, stripeCharge *stripe.Charge) {
+	if s.credentials == nil || stripeCharge == nil {
+		return
+	}
+
+	credential := chargeCredential(stripeCharge)
+	if credential == nil {
+		return
+	}
+
+	if err := s.credentials.RecordChargeCredential(ctx, credential); err != nil {
+		log.Printf("Failed to record credential for charge %s: %v", stripeCharge.ID, err)
+	}
+}
+
+// recordFailedCredential records the credential of a declined charge, which
+// Stripe creates even though the API call returns an error
+func (s *ChargeService) recordFailedCredential(ctx context.Context, err error) {
+	var stripeErr *stripe.Error
+	if s.credentials == nil || !errors.As(err, &stripeErr) || stripeErr.ChargeID == "" {
+		return
+	}
+
+	stripeCharge, getErr := charge.Get(stripeErr.ChargeID, nil)
+	if getErr != nil {
+		log.Printf("Failed to retrieve declined charge %s: %v", stripeErr.ChargeID, getErr)
+		return
+	}
+
+	s.recordCredential(ctx, stripeCharge)
+}
+
+// chargeCredential extracts the credential details of a card charge
+func chargeCredential(stripeCharge *stripe.Charge) *ChargeCredential {
+	if stripeCharge.PaymentMethodDetails == nil || stripeCharge.PaymentMethodDetails.Card == nil {
+		return nil
+	}
+
+	card := stripeCharge.PaymentMethodDetails.Card
+	credential := &ChargeCredential{
+		ChargeID:        stripeCharge.ID,
+		TenantID:        defaultTenantID,
+		PaymentMethodID: stripeCharge.PaymentMethod,
+		Network:         string(card.Network),
+		CredentialType:  credentialType(card),
+		Amount:          stripeCharge.Amount,
+		Currency:        string(stripeCharge.Currency),
+		Status:          string(stripeCharge.Status),
+		FailureCode:     stripeCharge.FailureCode,
+	}
+
+	if tenantID := stripeCharge.Metadata["tenant_id"]; tenantID != "" {
+		credential.TenantID = tenantID
+	}
+	if stripeCharge.Customer != nil {
+		credential.CustomerID = stripeCharge.Customer.ID
+	}
+	if card.Wallet != nil {
+		credential.Wallet = string(card.Wallet.Type)
+	}
+
+	return credential
+}
+
+// credentialType classifies the credential a card charge was authorized with
+func credentialType(card *stripe.ChargePaymentMethodDetailsCard) string {
+	switch {
+	case card.NetworkToken != nil && card.NetworkToken.Used:
+		return CredentialNetworkToken
+	case card.Wallet != nil:
+		return CredentialDPAN
+	default:
+		return CredentialPAN
+	}
+}
+
+// PreferTokenized orders payment methods so that those known to authorize
+// with a network token come first, for retries that should favour the
+// cheaper, higher-approval credential. The relative order is otherwise kept.
+func PreferTokenized(methods []*PaymentMethod, tokenized []string) []*PaymentMethod {
+	known := make(map[string]bool, len(tokenized))
+	for _, id := range tokenized {
+		known[id] = true
+	}
+
+	ordered := append([]*PaymentMethod(nil), methods...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return known[ordered[i].ID] && !known[ordered[j].ID]
+	})
+
+	return ordered
+}
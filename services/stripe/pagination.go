@@ -0,0 +1,32 @@
+package stripe
+
+import (
+	"github.com/stripe/stripe-go/v76"
+)
+
+// MaxPageLimit is the most objects Stripe returns in one list request
+const MaxPageLimit = 100
+
+// Page selects part of a list: at most Limit objects when Limit is positive,
+// starting after the object with ID StartingAfter when it is set. Lists are
+// newest first, so the last ID of one page is the cursor of the next.
+type Page struct {
+	Limit         int64
+	StartingAfter string
+}
+
+// apply sets the page's cursor and request size on Stripe list params.
+// Larger pages are fetched over several requests.
+func (p Page) apply(params *stripe.ListParams) {
+	if p.Limit > 0 {
+		params.Limit = stripe.Int64(min(p.Limit, MaxPageLimit))
+	}
+	if p.StartingAfter != "" {
+		params.StartingAfter = stripe.String(p.StartingAfter)
+	}
+}
+
+// full reports whether count objects fill the page
+func (p Page) full(count int) bool {
+	return p.Limit > 0 && int64(count) >= p.Limit
+}
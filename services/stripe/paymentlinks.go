@@ -0,0 +1,181 @@
+package stripe
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/paymentlink"
+	"github.com/stripe/stripe-go/v76/price"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// PaymentLinkService handles Stripe Payment Link operations
+type PaymentLinkService struct {
+	validator *validator.Validate
+	tracer    trace.Tracer
+}
+
+// NewPaymentLinkService creates a new payment link service
+func NewPaymentLinkService() *PaymentLinkService {
+	return &PaymentLinkService{
+		validator: validator.New(),
+		tracer:    otel.Tracer("payments.paymentlink"),
+	}
+}
+
+// PaymentLink represents a Stripe Payment Link
+type PaymentLink struct {
+	ID       string            `json:"id"`
+	URL      string            `json:"url"`
+	Active   bool              `json:"active"`
+	Currency string            `json:"currency"`
+	PriceID  string            `json:"price_id,omitempty"`
+	MaxUses  int64             `json:"max_uses,omitempty"` // Completed checkouts after which Stripe deactivates the link
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// PaymentLinkRequest creates a payment link for a fixed amount of one product
+type PaymentLinkRequest struct {
+	Amount      int64             `json:"amount" validate:"required,gt=0"`
+	Currency    string            `json:"currency" validate:"required,len=3"`
+	ProductName string            `json:"product_name" validate:"required,max=250"`
+	MaxUses     int64             `json:"max_uses,omitempty" validate:"gte=0"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+// CreatePaymentLink creates a payment link selling one unit of a new
+// product at the requested amount
+func (s *PaymentLinkService) CreatePaymentLink(ctx context.Context, request *PaymentLinkRequest) (*PaymentLink, error) {
+	ctx, span := s.tracer.Start(ctx, "CreatePaymentLink")
+	defer span.End()
+
+	if err := s.validator.Struct(request); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	stripePrice, err := price.New(&stripe.PriceParams{
+		Params:     stripe.Params{Context: ctx},
+		Currency:   stripe.String(request.Currency),
+		UnitAmount: stripe.Int64(request.Amount),
+		ProductData: &stripe.PriceProductDataParams{
+			Name: stripe.String(request.ProductName),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create price: %w", err)
+	}
+
+	params := &stripe.PaymentLinkParams{
+		LineItems: []*stripe.PaymentLinkLineItemParams{
+			{Price: stripe.String(stripePrice.ID), Quantity: stripe.Int64(1)},
+		},
+	}
+	if request.MaxUses > 0 {
+		params.Restrictions = &stripe.PaymentLinkRestrictionsParams{
+			CompletedSessions: &stripe.PaymentLinkRestrictionsCompletedSessionsParams{
+				Limit: stripe.Int64(request.MaxUses),
+			},
+		}
+	}
+	if len(request.Metadata) > 0 {
+		params.Metadata = request.Metadata
+	}
+
+	params.Context = ctx
+	stripeLink, err := paymentlink.New(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create payment link: %w", err)
+	}
+
+	link := ConvertPaymentLink(stripeLink)
+	link.PriceID = stripePrice.ID
+	return link, nil
+}
+
+// GetPaymentLink retrieves a payment link by ID
+func (s *PaymentLinkService) GetPaymentLink(ctx context.Context, linkID string) (*PaymentLink, error) {
+	ctx, span := s.tracer.Start(ctx, "GetPaymentLink")
+	defer span.End()
+
+	if linkID == "" {
+		return nil, fmt.Errorf("payment link ID cannot be empty")
+	}
+
+	stripeLink, err := paymentlink.Get(linkID, &stripe.PaymentLinkParams{Params: stripe.Params{Context: ctx}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve payment link: %w", err)
+	}
+
+	return ConvertPaymentLink(stripeLink), nil
+}
+
+// DeactivatePaymentLink deactivates a payment link. Customers visiting its
+// URL are told the link is no longer active.
+func (s *PaymentLinkService) DeactivatePaymentLink(ctx context.Context, linkID string) (*PaymentLink, error) {
+	ctx, span := s.tracer.Start(ctx, "DeactivatePaymentLink")
+	defer span.End()
+
+	if linkID == "" {
+		return nil, fmt.Errorf("payment link ID cannot be empty")
+	}
+
+	stripeLink, err := paymentlink.Update(linkID, &stripe.PaymentLinkParams{
+		Params: stripe.Params{Context: ctx},
+		Active: stripe.Bool(false),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to deactivate payment link: %w", err)
+	}
+
+	return ConvertPaymentLink(stripeLink), nil
+}
+
+// ListPaymentLinks lists payment links, newest first, optionally only active ones
+func (s *PaymentLinkService) ListPaymentLinks(ctx context.Context, activeOnly bool, page Page) ([]*PaymentLink, error) {
+	ctx, span := s.tracer.Start(ctx, "ListPaymentLinks")
+	defer span.End()
+
+	params := &stripe.PaymentLinkListParams{}
+	if activeOnly {
+		params.Active = stripe.Bool(true)
+	}
+	page.apply(&params.ListParams)
+
+	params.Context = ctx
+	iter := paymentlink.List(params)
+	var links []*PaymentLink
+
+	for !page.full(len(links)) && iter.Next() {
+		links = append(links, ConvertPaymentLink(iter.PaymentLink()))
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list payment links: %w", err)
+	}
+
+	return links, nil
+}
+
+// ConvertPaymentLink converts a Stripe payment link to our PaymentLink type
+func ConvertPaymentLink(stripeLink *stripe.PaymentLink) *PaymentLink {
+	link := &PaymentLink{
+		ID:       stripeLink.ID,
+		URL:      stripeLink.URL,
+		Active:   stripeLink.Active,
+		Currency: string(stripeLink.Currency),
+		Metadata: stripeLink.Metadata,
+	}
+
+	if stripeLink.Restrictions != nil && stripeLink.Restrictions.CompletedSessions != nil {
+		link.MaxUses = stripeLink.Restrictions.CompletedSessions.Limit
+	}
+	// Line items are only returned when expanded
+	if stripeLink.LineItems != nil && len(stripeLink.LineItems.Data) > 0 && stripeLink.LineItems.Data[0].Price != nil {
+		link.PriceID = stripeLink.LineItems.Data[0].Price.ID
+	}
+
+	return link
+}
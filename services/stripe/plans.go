@@ -0,0 +1,166 @@
+package stripe
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/price"
+)
+
+// PlanRequest creates a recurring price, either for an existing product or
+// for a new one named ProductName
+type PlanRequest struct {
+	ProductID     string `json:"product_id,omitempty" validate:"required_without=ProductName"`
+	ProductName   string `json:"product_name,omitempty" validate:"required_without=ProductID,max=250"`
+	Nickname      string `json:"nickname,omitempty" validate:"max=250"`
+	Amount        int64  `json:"amount" validate:"gte=0"`
+	Currency      string `json:"currency" validate:"required,len=3"`
+	Interval      string `json:"interval" validate:"required,oneof=day week month year"`
+	IntervalCount int64  `json:"interval_count,omitempty" validate:"gte=0"`
+	// UsageType metered bills reported usage instead of a quantity, totalled
+	// by AggregateUsage (default sum)
+	UsageType      string            `json:"usage_type,omitempty" validate:"omitempty,oneof=licensed metered"`
+	AggregateUsage string            `json:"aggregate_usage,omitempty" validate:"omitempty,oneof=sum max last_during_period last_ever"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+}
+
+// PlanUpdate changes what Stripe allows to change on a price. Amounts and
+// intervals are fixed once a price exists; create a new plan instead.
+// Nil and empty values are left unchanged.
+type PlanUpdate struct {
+	Nickname *string           `json:"nickname,omitempty" validate:"omitempty,max=250"`
+	Active   *bool             `json:"active,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// CreatePlan creates a recurring price subscriptions can be billed at
+func (s *SubscriptionService) CreatePlan(ctx context.Context, request *PlanRequest) (*SubscriptionPlan, error) {
+	ctx, span := s.tracer.Start(ctx, "CreatePlan")
+	defer span.End()
+
+	if err := s.validator.Struct(request); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	params := &stripe.PriceParams{
+		Currency:   stripe.String(request.Currency),
+		UnitAmount: stripe.Int64(request.Amount),
+		Recurring: &stripe.PriceRecurringParams{
+			Interval: stripe.String(request.Interval),
+		},
+	}
+	if request.IntervalCount > 0 {
+		params.Recurring.IntervalCount = stripe.Int64(request.IntervalCount)
+	}
+	if request.UsageType != "" {
+		params.Recurring.UsageType = stripe.String(request.UsageType)
+	}
+	if request.AggregateUsage != "" {
+		if request.UsageType != string(stripe.PriceRecurringUsageTypeMetered) {
+			return nil, fmt.Errorf("validation failed: aggregate_usage requires usage_type metered")
+		}
+		params.Recurring.AggregateUsage = stripe.String(request.AggregateUsage)
+	}
+	if request.ProductID != "" {
+		params.Product = stripe.String(request.ProductID)
+	} else {
+		params.ProductData = &stripe.PriceProductDataParams{Name: stripe.String(request.ProductName)}
+	}
+	if request.Nickname != "" {
+		params.Nickname = stripe.String(request.Nickname)
+	}
+	if len(request.Metadata) > 0 {
+		params.Metadata = request.Metadata
+	}
+
+	params.Context = ctx
+	stripePrice, err := price.New(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create plan: %w", err)
+	}
+
+	return ConvertPlan(stripePrice), nil
+}
+
+// GetPlan retrieves a plan by its price ID
+func (s *SubscriptionService) GetPlan(ctx context.Context, planID string) (*SubscriptionPlan, error) {
+	ctx, span := s.tracer.Start(ctx, "GetPlan")
+	defer span.End()
+
+	if planID == "" {
+		return nil, fmt.Errorf("plan ID cannot be empty")
+	}
+
+	stripePrice, err := price.Get(planID, &stripe.PriceParams{Params: stripe.Params{Context: ctx}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve plan: %w", err)
+	}
+
+	return ConvertPlan(stripePrice), nil
+}
+
+// ListPlans lists a page of recurring prices, newest first, optionally of
+// one product or only active ones
+func (s *SubscriptionService) ListPlans(ctx context.Context, productID string, activeOnly bool, page Page) ([]*SubscriptionPlan, error) {
+	ctx, span := s.tracer.Start(ctx, "ListPlans")
+	defer span.End()
+
+	params := &stripe.PriceListParams{
+		Type: stripe.String(string(stripe.PriceTypeRecurring)),
+	}
+	if productID != "" {
+		params.Product = stripe.String(productID)
+	}
+	if activeOnly {
+		params.Active = stripe.Bool(true)
+	}
+	page.apply(&params.ListParams)
+
+	params.Context = ctx
+	iter := price.List(params)
+	var plans []*SubscriptionPlan
+
+	for !page.full(len(plans)) && iter.Next() {
+		plans = append(plans, ConvertPlan(iter.Price()))
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list plans: %w", err)
+	}
+
+	return plans, nil
+}
+
+// UpdatePlan renames, archives or restores a plan and merges its metadata
+func (s *SubscriptionService) UpdatePlan(ctx context.Context, planID string, update *PlanUpdate) (*SubscriptionPlan, error) {
+	ctx, span := s.tracer.Start(ctx, "UpdatePlan")
+	defer span.End()
+
+	if planID == "" {
+		return nil, fmt.Errorf("plan ID cannot be empty")
+	}
+	if err := s.validator.Struct(update); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	params := &stripe.PriceParams{
+		Nickname: update.Nickname,
+		Active:   update.Active,
+		Metadata: update.Metadata,
+	}
+
+	params.Context = ctx
+	stripePrice, err := price.Update(planID, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update plan: %w", err)
+	}
+
+	return ConvertPlan(stripePrice), nil
+}
+
+// ArchivePlan deactivates a plan so no new subscriptions can use it.
+// Stripe prices cannot be deleted; existing subscriptions keep billing at it.
+func (s *SubscriptionService) ArchivePlan(ctx context.Context, planID string) (*SubscriptionPlan, error) {
+	return s.UpdatePlan(ctx, planID, &PlanUpdate{Active: stripe.Bool(false)})
+}
@@ -0,0 +1,140 @@
+package stripe
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/invoice"
+	"github.com/stripe/stripe-go/v76/subscription"
+)
+
+// Proration sources, telling whether a preview came from the provider or was
+// calculated locally
+const (
+	ProrationSourceProvider   = "provider"
+	ProrationSourceCalculated = "calculated"
+)
+
+// SubscriptionChange moves a single-price subscription to another price or
+// quantity. Empty values are left unchanged. Prorate defaults to true; the
+// proration date defaults to now and can be set to apply a previewed amount.
+type SubscriptionChange struct {
+	PriceID       string     `json:"price_id,omitempty"`
+	Quantity      int64      `json:"quantity,omitempty"`
+	Prorate       *bool      `json:"prorate,omitempty"`
+	ProrationDate *time.Time `json:"proration_date,omitempty"`
+}
+
+// ProrationPreview is what a subscription change would cost. Credit is the
+// unused time on the current price (negative), Charge the remaining time on
+// the new one; both are added to the next invoice.
+type ProrationPreview struct {
+	SubscriptionID   string `json:"subscription_id"`
+	PriceID          string `json:"price_id"`
+	Quantity         int64  `json:"quantity"`
+	Currency         string `json:"currency"`
+	ProrationDate    int64  `json:"proration_date"`
+	Credit           int64  `json:"credit"`
+	Charge           int64  `json:"charge"`
+	ProratedAmount   int64  `json:"prorated_amount"`
+	NextInvoiceTotal int64  `json:"next_invoice_total"`
+	NextInvoiceAt    int64  `json:"next_invoice_at"`
+	Source           string `json:"source"`
+}
+
+// PreviewSubscriptionChange previews the prorated amount of a change from
+// Stripe's upcoming invoice, without changing the subscription
+func (s *SubscriptionService) PreviewSubscriptionChange(ctx context.Context, subscriptionID string, change *SubscriptionChange) (*ProrationPreview, error) {
+	ctx, span := s.tracer.Start(ctx, "PreviewSubscriptionChange")
+	defer span.End()
+
+	if subscriptionID == "" {
+		return nil, fmt.Errorf("subscription ID cannot be empty")
+	}
+
+	current, err := subscription.Get(subscriptionID, &stripe.SubscriptionParams{Params: stripe.Params{Context: ctx}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve subscription: %w", err)
+	}
+	item, err := singleItem(current)
+	if err != nil {
+		return nil, err
+	}
+
+	prorationDate := time.Now()
+	if change.ProrationDate != nil {
+		prorationDate = *change.ProrationDate
+	}
+
+	params := &stripe.InvoiceUpcomingParams{
+		Customer:                      stripe.String(current.Customer.ID),
+		Subscription:                  stripe.String(subscriptionID),
+		SubscriptionItems:             changedItems(item, change),
+		SubscriptionProrationBehavior: stripe.String(string(stripe.SubscriptionSchedulePhaseProrationBehaviorCreateProrations)),
+		SubscriptionProrationDate:     stripe.Int64(prorationDate.Unix()),
+	}
+	params.Context = ctx
+	upcoming, err := invoice.Upcoming(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to preview subscription change: %w", err)
+	}
+
+	preview := &ProrationPreview{
+		SubscriptionID:   subscriptionID,
+		PriceID:          item.Price.ID,
+		Quantity:         item.Quantity,
+		Currency:         string(upcoming.Currency),
+		ProrationDate:    prorationDate.Unix(),
+		NextInvoiceTotal: upcoming.Total,
+		NextInvoiceAt:    upcoming.NextPaymentAttempt,
+		Source:           ProrationSourceProvider,
+	}
+	if change.PriceID != "" {
+		preview.PriceID = change.PriceID
+	}
+	if change.Quantity > 0 {
+		preview.Quantity = change.Quantity
+	}
+	if preview.NextInvoiceAt == 0 {
+		preview.NextInvoiceAt = current.CurrentPeriodEnd
+	}
+
+	if upcoming.Lines != nil {
+		for _, line := range upcoming.Lines.Data {
+			if !line.Proration {
+				continue
+			}
+			if line.Amount < 0 {
+				preview.Credit += line.Amount
+			} else {
+				preview.Charge += line.Amount
+			}
+		}
+	}
+	preview.ProratedAmount = preview.Credit + preview.Charge
+
+	return preview, nil
+}
+
+// singleItem returns the only item of a subscription; changes are made to
+// single-price subscriptions only
+func singleItem(stripeSubscription *stripe.Subscription) (*stripe.SubscriptionItem, error) {
+	if stripeSubscription.Items == nil || len(stripeSubscription.Items.Data) != 1 {
+		return nil, fmt.Errorf("subscription %s does not have exactly one price", stripeSubscription.ID)
+	}
+	return stripeSubscription.Items.Data[0], nil
+}
+
+// changedItems replaces the price and quantity of a subscription item
+func changedItems(item *stripe.SubscriptionItem, change *SubscriptionChange) []*stripe.SubscriptionItemsParams {
+	params := &stripe.SubscriptionItemsParams{ID: stripe.String(item.ID)}
+	if change.PriceID != "" {
+		params.Price = stripe.String(change.PriceID)
+	}
+	if change.Quantity > 0 {
+		params.Quantity = stripe.Int64(change.Quantity)
+	}
+	return []*stripe.SubscriptionItemsParams{params}
+}
@@ -0,0 +1,223 @@
+package stripe
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/radar/valuelist"
+	"github.com/stripe/stripe-go/v76/radar/valuelistitem"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RadarService manages Stripe Radar value lists. Radar rules themselves are
+// not exposed by the Stripe API; rules reference value lists by alias, e.g.
+// "Block if :email: in @blocked_emails", so lists are what we manage.
+type RadarService struct {
+	tracer trace.Tracer
+}
+
+// NewRadarService creates a new Radar service
+func NewRadarService() *RadarService {
+	return &RadarService{
+		tracer: otel.Tracer("payments.radar"),
+	}
+}
+
+// ValueList represents a Radar value list
+type ValueList struct {
+	ID       string            `json:"id"`
+	Alias    string            `json:"alias"`
+	Name     string            `json:"name"`
+	ItemType string            `json:"item_type"`
+	Items    []*ValueListItem  `json:"items,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Created  int64             `json:"created"`
+}
+
+// ValueListItem represents one value in a Radar value list
+type ValueListItem struct {
+	ID          string `json:"id"`
+	ValueListID string `json:"value_list_id"`
+	Value       string `json:"value"`
+	CreatedBy   string `json:"created_by,omitempty"`
+	Created     int64  `json:"created"`
+}
+
+// ValueListRequest represents a request to create a value list
+type ValueListRequest struct {
+	Alias    string            `json:"alias" validate:"required"`
+	Name     string            `json:"name" validate:"required"`
+	ItemType string            `json:"item_type"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// ListValueLists lists Radar value lists
+func (s *RadarService) ListValueLists(ctx context.Context) ([]*ValueList, error) {
+	ctx, span := s.tracer.Start(ctx, "ListValueLists")
+	defer span.End()
+
+	var lists []*ValueList
+	iter := valuelist.List(&stripe.RadarValueListListParams{ListParams: stripe.ListParams{Context: ctx}})
+	for iter.Next() {
+		lists = append(lists, convertValueList(iter.RadarValueList()))
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list value lists: %w", err)
+	}
+
+	return lists, nil
+}
+
+// FindValueList returns the value list with an alias, or nil if none exists
+func (s *RadarService) FindValueList(ctx context.Context, alias string) (*ValueList, error) {
+	ctx, span := s.tracer.Start(ctx, "FindValueList")
+	defer span.End()
+
+	params := &stripe.RadarValueListListParams{Alias: stripe.String(alias)}
+
+	params.Context = ctx
+	iter := valuelist.List(params)
+	for iter.Next() {
+		return convertValueList(iter.RadarValueList()), nil
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to find value list: %w", err)
+	}
+
+	return nil, nil
+}
+
+// CreateValueList creates a Radar value list
+func (s *RadarService) CreateValueList(ctx context.Context, request *ValueListRequest) (*ValueList, error) {
+	ctx, span := s.tracer.Start(ctx, "CreateValueList")
+	defer span.End()
+
+	if request.Alias == "" || request.Name == "" {
+		return nil, fmt.Errorf("alias and name are required")
+	}
+
+	params := &stripe.RadarValueListParams{
+		Alias:    stripe.String(request.Alias),
+		Name:     stripe.String(request.Name),
+		Metadata: request.Metadata,
+	}
+	if request.ItemType != "" {
+		params.ItemType = stripe.String(request.ItemType)
+	}
+
+	params.Context = ctx
+	list, err := valuelist.New(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create value list: %w", err)
+	}
+
+	return convertValueList(list), nil
+}
+
+// GetValueList retrieves a Radar value list with its items
+func (s *RadarService) GetValueList(ctx context.Context, listID string) (*ValueList, error) {
+	ctx, span := s.tracer.Start(ctx, "GetValueList")
+	defer span.End()
+
+	list, err := valuelist.Get(listID, &stripe.RadarValueListParams{Params: stripe.Params{Context: ctx}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get value list: %w", err)
+	}
+
+	result := convertValueList(list)
+	result.Items, err = s.ListValueListItems(ctx, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// DeleteValueList deletes a Radar value list and its items. Stripe refuses
+// to delete lists still referenced by rules.
+func (s *RadarService) DeleteValueList(ctx context.Context, listID string) error {
+	ctx, span := s.tracer.Start(ctx, "DeleteValueList")
+	defer span.End()
+
+	if _, err := valuelist.Del(listID, &stripe.RadarValueListParams{Params: stripe.Params{Context: ctx}}); err != nil {
+		return fmt.Errorf("failed to delete value list: %w", err)
+	}
+
+	return nil
+}
+
+// ListValueListItems lists every item in a Radar value list
+func (s *RadarService) ListValueListItems(ctx context.Context, listID string) ([]*ValueListItem, error) {
+	ctx, span := s.tracer.Start(ctx, "ListValueListItems")
+	defer span.End()
+
+	params := &stripe.RadarValueListItemListParams{ValueList: stripe.String(listID)}
+
+	var items []*ValueListItem
+	params.Context = ctx
+	iter := valuelistitem.List(params)
+	for iter.Next() {
+		items = append(items, convertValueListItem(iter.RadarValueListItem()))
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list value list items: %w", err)
+	}
+
+	return items, nil
+}
+
+// AddValueListItem adds a value to a Radar value list
+func (s *RadarService) AddValueListItem(ctx context.Context, listID, value string) (*ValueListItem, error) {
+	ctx, span := s.tracer.Start(ctx, "AddValueListItem")
+	defer span.End()
+
+	params := &stripe.RadarValueListItemParams{
+		ValueList: stripe.String(listID),
+		Value:     stripe.String(value),
+	}
+
+	params.Context = ctx
+	item, err := valuelistitem.New(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add value list item: %w", err)
+	}
+
+	return convertValueListItem(item), nil
+}
+
+// RemoveValueListItem removes a value from its Radar value list
+func (s *RadarService) RemoveValueListItem(ctx context.Context, itemID string) error {
+	ctx, span := s.tracer.Start(ctx, "RemoveValueListItem")
+	defer span.End()
+
+	if _, err := valuelistitem.Del(itemID, &stripe.RadarValueListItemParams{Params: stripe.Params{Context: ctx}}); err != nil {
+		return fmt.Errorf("failed to remove value list item: %w", err)
+	}
+
+	return nil
+}
+
+// convertValueList converts a Stripe value list to our value list type
+func convertValueList(list *stripe.RadarValueList) *ValueList {
+	return &ValueList{
+		ID:       list.ID,
+		Alias:    list.Alias,
+		Name:     list.Name,
+		ItemType: string(list.ItemType),
+		Metadata: list.Metadata,
+		Created:  list.Created,
+	}
+}
+
+// convertValueListItem converts a Stripe value list item to our item type
+func convertValueListItem(item *stripe.RadarValueListItem) *ValueListItem {
+	return &ValueListItem{
+		ID:          item.ID,
+		ValueListID: item.ValueList,
+		Value:       item.Value,
+		CreatedBy:   item.CreatedBy,
+		Created:     item.Created,
+	}
+}
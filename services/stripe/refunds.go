@@ -5,46 +5,62 @@ import (
 	"fmt"
 	"time"
 
+	"apis/payments/services/money"
+
 	"github.com/go-playground/validator/v10"
 	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/charge"
 	"github.com/stripe/stripe-go/v76/refund"
 )
 
 // RefundService handles Stripe refund operations
 type RefundService struct {
 	validator *validator.Validate
+	mirror    Mirror
 }
 
 // NewRefundService creates a new refund service
 func NewRefundService() *RefundService {
 	return &RefundService{
 		validator: validator.New(),
+		mirror:    noMirror{},
 	}
 }
 
 // RefundRequest represents a request to create a refund
 type RefundRequest struct {
-	ChargeID string            `json:"charge_id" validate:"required"`
-	Amount   int64             `json:"amount,omitempty"` // Optional, if not provided, refunds entire charge
-	Reason   string            `json:"reason,omitempty"` // requested_by_customer, duplicate, fraudulent
-	Metadata map[string]string `json:"metadata,omitempty"`
+	ChargeID       string            `json:"charge_id" validate:"required"`
+	Amount         int64             `json:"amount,omitempty"`         // Optional, if not provided, refunds entire charge
+	AmountDecimal  string            `json:"amount_decimal,omitempty"` // Alternative to amount in the charge's currency, e.g. "10.00"
+	Reason         string            `json:"reason,omitempty"`         // requested_by_customer, duplicate, fraudulent
+	Metadata       map[string]string `json:"metadata,omitempty"`
+	IdempotencyKey string            `json:"-"` // Set by callers that may retry, such as command consumers
 }
 
 // Refund represents a Stripe refund
 type Refund struct {
-	ID          string            `json:"id"`
-	ChargeID    string            `json:"charge_id"`
-	Amount      int64             `json:"amount"`
-	Currency    string            `json:"currency"`
-	Status      string            `json:"status"`
-	Reason      string            `json:"reason,omitempty"`
-	Metadata    map[string]string `json:"metadata,omitempty"`
-	CreatedAt   time.Time         `json:"created_at"`
-	UpdatedAt   time.Time         `json:"updated_at"`
+	ID            string            `json:"id"`
+	ChargeID      string            `json:"charge_id"`
+	Amount        int64             `json:"amount"`
+	AmountDecimal string            `json:"amount_decimal"`
+	AmountDisplay money.Display     `json:"amount_display"`
+	Currency      string            `json:"currency"`
+	Status        string            `json:"status"`
+	Reason        string            `json:"reason,omitempty"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
 }
 
 // CreateRefund creates a new refund using Stripe
 func (s *RefundService) CreateRefund(ctx context.Context, request *RefundRequest) (*Refund, error) {
+	// Convert a decimal amount using the charge's currency
+	if request.AmountDecimal != "" {
+		if err := s.ResolveAmount(ctx, request); err != nil {
+			return nil, err
+		}
+	}
+
 	// Validate the request
 	if err := s.validator.Struct(request); err != nil {
 		return nil, fmt.Errorf("validation failed: %w", err)
@@ -66,6 +82,12 @@ func (s *RefundService) CreateRefund(ctx context.Context, request *RefundRequest
 		params.Metadata = request.Metadata
 	}
 
+	// A retried request returns the refund created by the first attempt
+	if request.IdempotencyKey != "" {
+		params.SetIdempotencyKey(request.IdempotencyKey)
+	}
+	params.Context = ctx
+
 	// Create the refund
 	stripeRefund, err := refund.New(params)
 	if err != nil {
@@ -74,20 +96,99 @@ func (s *RefundService) CreateRefund(ctx context.Context, request *RefundRequest
 
 	// Convert to our Refund type
 	refund := &Refund{
-		ID:        stripeRefund.ID,
-		ChargeID:  stripeRefund.Charge.ID,
-		Amount:    stripeRefund.Amount,
-		Currency:  string(stripeRefund.Currency),
-		Status:    string(stripeRefund.Status),
-		Reason:    string(stripeRefund.Reason),
-		Metadata:  stripeRefund.Metadata,
-		CreatedAt: time.Unix(stripeRefund.Created, 0),
-		UpdatedAt: time.Unix(stripeRefund.Created, 0), // Stripe doesn't provide updated_at for refunds
+		ID:            stripeRefund.ID,
+		ChargeID:      stripeRefund.Charge.ID,
+		Amount:        stripeRefund.Amount,
+		AmountDecimal: money.FormatDecimal(stripeRefund.Amount, string(stripeRefund.Currency)),
+		AmountDisplay: money.Describe(stripeRefund.Amount, string(stripeRefund.Currency)),
+		Currency:      string(stripeRefund.Currency),
+		Status:        string(stripeRefund.Status),
+		Reason:        string(stripeRefund.Reason),
+		Metadata:      stripeRefund.Metadata,
+		CreatedAt:     time.Unix(stripeRefund.Created, 0),
+		UpdatedAt:     time.Unix(stripeRefund.Created, 0), // Stripe doesn't provide updated_at for refunds
+	}
+	s.mirror.SaveRefund(ctx, refund)
+
+	return refund, nil
+}
+
+// CashBalanceRefundRequest returns unapplied customer cash balance funds,
+// e.g. an unmatched bank transfer, to the customer
+type CashBalanceRefundRequest struct {
+	CustomerID     string `validate:"required"`
+	Currency       string `validate:"required"`
+	Amount         int64  `validate:"required,min=1"`
+	IdempotencyKey string `validate:"required"`
+	Metadata       map[string]string
+}
+
+// RefundCashBalance refunds funds from a customer's cash balance. Stripe
+// emails the customer for bank details when it has none on file.
+func (s *RefundService) RefundCashBalance(ctx context.Context, request *CashBalanceRefundRequest) (*Refund, error) {
+	if err := s.validator.Struct(request); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	params := &stripe.RefundParams{
+		Customer: stripe.String(request.CustomerID),
+		Currency: stripe.String(request.Currency),
+		Amount:   stripe.Int64(request.Amount),
+		Origin:   stripe.String("customer_balance"),
+	}
+	params.SetIdempotencyKey(request.IdempotencyKey)
+	if len(request.Metadata) > 0 {
+		params.Metadata = request.Metadata
+	}
+	params.Context = ctx
+
+	stripeRefund, err := refund.New(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Stripe refund: %w", err)
+	}
+
+	refund := &Refund{
+		ID:            stripeRefund.ID,
+		Amount:        stripeRefund.Amount,
+		AmountDecimal: money.FormatDecimal(stripeRefund.Amount, string(stripeRefund.Currency)),
+		AmountDisplay: money.Describe(stripeRefund.Amount, string(stripeRefund.Currency)),
+		Currency:      string(stripeRefund.Currency),
+		Status:        string(stripeRefund.Status),
+		Metadata:      stripeRefund.Metadata,
+		CreatedAt:     time.Unix(stripeRefund.Created, 0),
+		UpdatedAt:     time.Unix(stripeRefund.Created, 0),
 	}
+	s.mirror.SaveRefund(ctx, refund)
 
 	return refund, nil
 }
 
+// ResolveAmount converts a decimal refund amount to minor units in the
+// currency of the refunded charge
+func (s *RefundService) ResolveAmount(ctx context.Context, request *RefundRequest) error {
+	if request.AmountDecimal == "" {
+		return nil
+	}
+
+	if request.ChargeID == "" {
+		return fmt.Errorf("validation failed: charge ID is required")
+	}
+
+	stripeCharge, err := charge.Get(request.ChargeID, &stripe.ChargeParams{Params: stripe.Params{Context: ctx}})
+	if err != nil {
+		return fmt.Errorf("failed to retrieve charge: %w", err)
+	}
+
+	amount, err := money.ResolveAmount(request.Amount, request.AmountDecimal, string(stripeCharge.Currency))
+	if err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+
+	request.Amount = amount
+	request.AmountDecimal = ""
+	return nil
+}
+
 // GetRefund retrieves a refund by ID
 func (s *RefundService) GetRefund(ctx context.Context, refundID string) (*Refund, error) {
 	if refundID == "" {
@@ -95,64 +196,50 @@ func (s *RefundService) GetRefund(ctx context.Context, refundID string) (*Refund
 	}
 
 	// Retrieve the refund from Stripe
-	stripeRefund, err := refund.Get(refundID, nil)
+	stripeRefund, err := refund.Get(refundID, &stripe.RefundParams{Params: stripe.Params{Context: ctx}})
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve Stripe refund: %w", err)
 	}
 
-	// Convert to our Refund type
-	refund := &Refund{
-		ID:        stripeRefund.ID,
-		ChargeID:  stripeRefund.Charge.ID,
-		Amount:    stripeRefund.Amount,
-		Currency:  string(stripeRefund.Currency),
-		Status:    string(stripeRefund.Status),
-		Reason:    string(stripeRefund.Reason),
-		Metadata:  stripeRefund.Metadata,
-		CreatedAt: time.Unix(stripeRefund.Created, 0),
-		UpdatedAt: time.Unix(stripeRefund.Created, 0),
+	return ConvertRefund(stripeRefund), nil
+}
+
+// UpdateRefund replaces the metadata of a refund
+func (s *RefundService) UpdateRefund(ctx context.Context, refundID string, metadata map[string]string) (*Refund, error) {
+	if refundID == "" {
+		return nil, fmt.Errorf("refund ID is required")
 	}
 
-	return refund, nil
+	stripeRefund, err := refund.Update(refundID, &stripe.RefundParams{Params: stripe.Params{Context: ctx}, Metadata: metadata})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update Stripe refund: %w", err)
+	}
+
+	updated := ConvertRefund(stripeRefund)
+	s.mirror.SaveRefund(ctx, updated)
+
+	return updated, nil
 }
 
-// ListRefunds lists refunds for a specific charge
-func (s *RefundService) ListRefunds(ctx context.Context, chargeID string, limit int) ([]*Refund, error) {
+// ListRefunds lists a page of refunds for a specific charge, newest first
+func (s *RefundService) ListRefunds(ctx context.Context, chargeID string, page Page) ([]*Refund, error) {
 	if chargeID == "" {
 		return nil, fmt.Errorf("charge ID is required")
 	}
 
-	// Set default limit if not provided
-	if limit <= 0 {
-		limit = 100
-	}
-
 	// Create Stripe list params
 	params := &stripe.RefundListParams{
 		Charge: stripe.String(chargeID),
 	}
+	page.apply(&params.ListParams)
+	params.Context = ctx
 
 	// List refunds from Stripe
 	iter := refund.List(params)
 	var refunds []*Refund
 
-	for iter.Next() {
-		stripeRefund := iter.Refund()
-		
-		// Convert to our Refund type
-		refund := &Refund{
-			ID:        stripeRefund.ID,
-			ChargeID:  stripeRefund.Charge.ID,
-			Amount:    stripeRefund.Amount,
-			Currency:  string(stripeRefund.Currency),
-			Status:    string(stripeRefund.Status),
-			Reason:    string(stripeRefund.Reason),
-			Metadata:  stripeRefund.Metadata,
-			CreatedAt: time.Unix(stripeRefund.Created, 0),
-			UpdatedAt: time.Unix(stripeRefund.Created, 0),
-		}
-		
-		refunds = append(refunds, refund)
+	for !page.full(len(refunds)) && iter.Next() {
+		refunds = append(refunds, ConvertRefund(iter.Refund()))
 	}
 
 	if err := iter.Err(); err != nil {
@@ -162,6 +249,29 @@ func (s *RefundService) ListRefunds(ctx context.Context, chargeID string, limit
 	return refunds, nil
 }
 
+// ConvertRefund converts a Stripe refund to our Refund type
+func ConvertRefund(stripeRefund *stripe.Refund) *Refund {
+	refund := &Refund{
+		ID:            stripeRefund.ID,
+		Amount:        stripeRefund.Amount,
+		AmountDecimal: money.FormatDecimal(stripeRefund.Amount, string(stripeRefund.Currency)),
+		AmountDisplay: money.Describe(stripeRefund.Amount, string(stripeRefund.Currency)),
+		Currency:      string(stripeRefund.Currency),
+		Status:        string(stripeRefund.Status),
+		Reason:        string(stripeRefund.Reason),
+		Metadata:      stripeRefund.Metadata,
+		CreatedAt:     time.Unix(stripeRefund.Created, 0),
+		UpdatedAt:     time.Unix(stripeRefund.Created, 0), // Stripe doesn't provide updated_at for refunds
+	}
+
+	// Cash balance refunds have no charge
+	if stripeRefund.Charge != nil {
+		refund.ChargeID = stripeRefund.Charge.ID
+	}
+
+	return refund
+}
+
 // ValidateRefundRequest validates a refund request
 func (s *RefundService) ValidateRefundRequest(request *RefundRequest) error {
 	if err := s.validator.Struct(request); err != nil {
@@ -0,0 +1,279 @@
+package stripe
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/subscription"
+	"github.com/stripe/stripe-go/v76/subscriptionschedule"
+)
+
+// Metadata keys written to the subscription schedule and to the phase that
+// applies a scheduled plan change
+const (
+	metadataPlanChangeFrom = "plan_change_from"
+	metadataPlanChangeTo   = "plan_change_to"
+)
+
+// ErrNoScheduledChange is returned when canceling a change that does not exist
+var ErrNoScheduledChange = errors.New("subscription has no scheduled plan change")
+
+// ErrScheduleConflict is returned when a subscription is already managed by
+// a schedule that this service did not create
+var ErrScheduleConflict = errors.New("subscription is managed by another schedule")
+
+// PlanChange describes a plan change scheduled for the end of the current period
+type PlanChange struct {
+	SubscriptionID string `json:"subscription_id"`
+	CustomerID     string `json:"customer_id,omitempty"`
+	ScheduleID     string `json:"schedule_id"`
+	FromPriceID    string `json:"from_price_id"`
+	ToPriceID      string `json:"to_price_id"`
+	EffectiveAt    int64  `json:"effective_at"`
+}
+
+// PlanChangeRequest represents a request to change a subscription's plan at period end
+type PlanChangeRequest struct {
+	PriceID string `json:"price_id" validate:"required"`
+}
+
+// PlanChangeListener is notified when a scheduled plan change takes effect
+type PlanChangeListener func(ctx context.Context, change *PlanChange) error
+
+// OnPlanChangeApplied registers a listener for applied plan changes
+func (s *SubscriptionService) OnPlanChangeApplied(listener PlanChangeListener) {
+	s.planChangeListeners = append(s.planChangeListeners, listener)
+}
+
+// SchedulePlanChange switches the subscription to a new price at the end of
+// the current period. Any previously scheduled change is replaced.
+func (s *SubscriptionService) SchedulePlanChange(ctx context.Context, subscriptionID string, request *PlanChangeRequest) (*Subscription, error) {
+	ctx, span := s.tracer.Start(ctx, "SchedulePlanChange")
+	defer span.End()
+
+	if subscriptionID == "" {
+		return nil, fmt.Errorf("subscription ID cannot be empty")
+	}
+
+	if err := s.validator.Struct(request); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	stripeSubscription, err := getSubscriptionWithSchedule(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if stripeSubscription.Items == nil || len(stripeSubscription.Items.Data) != 1 {
+		return nil, fmt.Errorf("only single-item subscriptions support scheduled plan changes")
+	}
+	item := stripeSubscription.Items.Data[0]
+	if item.Price == nil {
+		return nil, fmt.Errorf("subscription item has no price")
+	}
+	if item.Price.ID == request.PriceID {
+		return nil, fmt.Errorf("subscription is already on price %s", request.PriceID)
+	}
+
+	scheduleID, err := s.planChangeSchedule(ctx, stripeSubscription)
+	if err != nil {
+		return nil, err
+	}
+
+	// The new price's phase metadata is copied to the subscription when it
+	// starts, which is how the applied change is recognised in webhooks
+	phaseMetadata := make(map[string]string, len(stripeSubscription.Metadata)+2)
+	for key, value := range stripeSubscription.Metadata {
+		phaseMetadata[key] = value
+	}
+	phaseMetadata[metadataPlanChangeFrom] = item.Price.ID
+	phaseMetadata[metadataPlanChangeTo] = request.PriceID
+
+	params := &stripe.SubscriptionScheduleParams{
+		EndBehavior: stripe.String(string(stripe.SubscriptionScheduleEndBehaviorRelease)),
+		Metadata: map[string]string{
+			metadataPlanChangeFrom: item.Price.ID,
+			metadataPlanChangeTo:   request.PriceID,
+		},
+		Phases: []*stripe.SubscriptionSchedulePhaseParams{
+			{
+				Items: []*stripe.SubscriptionSchedulePhaseItemParams{
+					{Price: stripe.String(item.Price.ID), Quantity: stripe.Int64(item.Quantity)},
+				},
+				StartDate: stripe.Int64(stripeSubscription.CurrentPeriodStart),
+				EndDate:   stripe.Int64(stripeSubscription.CurrentPeriodEnd),
+				Metadata:  stripeSubscription.Metadata,
+			},
+			{
+				Items: []*stripe.SubscriptionSchedulePhaseItemParams{
+					{Price: stripe.String(request.PriceID), Quantity: stripe.Int64(item.Quantity)},
+				},
+				Iterations:        stripe.Int64(1),
+				ProrationBehavior: stripe.String(string(stripe.SubscriptionSchedulePhaseProrationBehaviorNone)),
+				Metadata:          phaseMetadata,
+			},
+		},
+	}
+
+	params.Context = ctx
+	if _, err := subscriptionschedule.Update(scheduleID, params); err != nil {
+		return nil, fmt.Errorf("failed to schedule plan change: %w", err)
+	}
+
+	return s.GetSubscription(ctx, subscriptionID)
+}
+
+// CancelPlanChange cancels a scheduled plan change before it applies. The
+// subscription keeps its current plan.
+func (s *SubscriptionService) CancelPlanChange(ctx context.Context, subscriptionID string) (*Subscription, error) {
+	ctx, span := s.tracer.Start(ctx, "CancelPlanChange")
+	defer span.End()
+
+	if subscriptionID == "" {
+		return nil, fmt.Errorf("subscription ID cannot be empty")
+	}
+
+	stripeSubscription, err := getSubscriptionWithSchedule(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	change := pendingPlanChange(stripeSubscription)
+	if change == nil {
+		return nil, ErrNoScheduledChange
+	}
+
+	if _, err := subscriptionschedule.Release(change.ScheduleID, &stripe.SubscriptionScheduleReleaseParams{Params: stripe.Params{Context: ctx}}); err != nil {
+		return nil, fmt.Errorf("failed to cancel plan change: %w", err)
+	}
+
+	return s.GetSubscription(ctx, subscriptionID)
+}
+
+// RegisterWebhookHandlers notifies plan change listeners when Stripe applies
+// a scheduled change to a subscription
+func (s *SubscriptionService) RegisterWebhookHandlers(webhooks *WebhookService) {
+	webhooks.On(stripe.EventTypeCustomerSubscriptionUpdated, func(ctx context.Context, event stripe.Event) error {
+		if _, itemsChanged := event.Data.PreviousAttributes["items"]; !itemsChanged {
+			return nil
+		}
+
+		var stripeSubscription stripe.Subscription
+		if err := json.Unmarshal(event.Data.Raw, &stripeSubscription); err != nil {
+			return fmt.Errorf("failed to parse subscription: %w", err)
+		}
+
+		change := appliedPlanChange(&stripeSubscription)
+		if change == nil {
+			return nil
+		}
+
+		log.Printf("Applied scheduled plan change for subscription %s: %s -> %s", change.SubscriptionID, change.FromPriceID, change.ToPriceID)
+		for _, listener := range s.planChangeListeners {
+			if err := listener(ctx, change); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// planChangeSchedule returns the schedule to write a plan change to, creating
+// one from the subscription if it has none
+func (s *SubscriptionService) planChangeSchedule(ctx context.Context, stripeSubscription *stripe.Subscription) (string, error) {
+	if stripeSubscription.Schedule != nil {
+		if stripeSubscription.Schedule.Metadata[metadataPlanChangeTo] == "" {
+			return "", ErrScheduleConflict
+		}
+		return stripeSubscription.Schedule.ID, nil
+	}
+
+	schedule, err := subscriptionschedule.New(&stripe.SubscriptionScheduleParams{
+		Params:           stripe.Params{Context: ctx},
+		FromSubscription: stripe.String(stripeSubscription.ID),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create subscription schedule: %w", err)
+	}
+
+	return schedule.ID, nil
+}
+
+// getSubscriptionWithSchedule retrieves a subscription with its schedule expanded
+func getSubscriptionWithSchedule(ctx context.Context, subscriptionID string) (*stripe.Subscription, error) {
+	params := &stripe.SubscriptionParams{}
+	params.AddExpand("schedule")
+
+	params.Context = ctx
+	stripeSubscription, err := subscription.Get(subscriptionID, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve subscription: %w", err)
+	}
+
+	return stripeSubscription, nil
+}
+
+// pendingPlanChange returns the plan change scheduled on an expanded schedule, if any
+func pendingPlanChange(stripeSubscription *stripe.Subscription) *PlanChange {
+	schedule := stripeSubscription.Schedule
+	if schedule == nil || schedule.Metadata[metadataPlanChangeTo] == "" {
+		return nil
+	}
+
+	change := &PlanChange{
+		SubscriptionID: stripeSubscription.ID,
+		ScheduleID:     schedule.ID,
+		FromPriceID:    schedule.Metadata[metadataPlanChangeFrom],
+		ToPriceID:      schedule.Metadata[metadataPlanChangeTo],
+		EffectiveAt:    stripeSubscription.CurrentPeriodEnd,
+	}
+	if stripeSubscription.Customer != nil {
+		change.CustomerID = stripeSubscription.Customer.ID
+	}
+
+	// Once the new phase has started the change is no longer pending
+	for _, phase := range schedule.Phases {
+		if phase.Metadata[metadataPlanChangeTo] == change.ToPriceID {
+			if schedule.CurrentPhase != nil && schedule.CurrentPhase.StartDate >= phase.StartDate {
+				return nil
+			}
+			change.EffectiveAt = phase.StartDate
+		}
+	}
+
+	return change
+}
+
+// appliedPlanChange returns the plan change that the subscription's current
+// price came from, if its metadata marks one
+func appliedPlanChange(stripeSubscription *stripe.Subscription) *PlanChange {
+	toPriceID := stripeSubscription.Metadata[metadataPlanChangeTo]
+	if toPriceID == "" || stripeSubscription.Items == nil || len(stripeSubscription.Items.Data) == 0 {
+		return nil
+	}
+
+	item := stripeSubscription.Items.Data[0]
+	if item.Price == nil || item.Price.ID != toPriceID {
+		return nil
+	}
+
+	change := &PlanChange{
+		SubscriptionID: stripeSubscription.ID,
+		FromPriceID:    stripeSubscription.Metadata[metadataPlanChangeFrom],
+		ToPriceID:      toPriceID,
+		EffectiveAt:    stripeSubscription.CurrentPeriodStart,
+	}
+	if stripeSubscription.Customer != nil {
+		change.CustomerID = stripeSubscription.Customer.ID
+	}
+	if stripeSubscription.Schedule != nil {
+		change.ScheduleID = stripeSubscription.Schedule.ID
+	}
+
+	return change
+}
@@ -0,0 +1,251 @@
+package stripe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/paymentmethod"
+	"github.com/stripe/stripe-go/v76/setupintent"
+)
+
+// Setup intent statuses
+const (
+	SetupIntentRequiresPaymentMethod = "requires_payment_method"
+	SetupIntentRequiresConfirmation  = "requires_confirmation"
+	SetupIntentRequiresAction        = "requires_action"
+	SetupIntentProcessing            = "processing"
+	SetupIntentSucceeded             = "succeeded"
+	SetupIntentCanceled              = "canceled"
+)
+
+// ErrSetupIntentNotSucceeded is returned when reading the payment method of a
+// setup intent that has not succeeded
+var ErrSetupIntentNotSucceeded = errors.New("setup intent has not succeeded")
+
+// SetupIntent represents a Stripe setup intent: the saving of a payment
+// method for later payments. The client secret lets the frontend collect and
+// confirm card details with Stripe.js, so they never reach this service.
+type SetupIntent struct {
+	ID                 string            `json:"id"`
+	ClientSecret       string            `json:"client_secret,omitempty"`
+	Customer           string            `json:"customer"`
+	Status             string            `json:"status"`
+	Usage              string            `json:"usage"`
+	PaymentMethod      string            `json:"payment_method,omitempty"`
+	PaymentMethodTypes []string          `json:"payment_method_types,omitempty"`
+	NextActionType     string            `json:"next_action_type,omitempty"` // Set while the customer must complete an action, e.g. 3D Secure
+	Mandate            string            `json:"mandate,omitempty"`
+	LastError          string            `json:"last_error,omitempty"`
+	Metadata           map[string]string `json:"metadata,omitempty"`
+	Created            int64             `json:"created"`
+
+	// Set while an ACH bank account awaits micro-deposit verification
+	Microdeposits *Microdeposits `json:"microdeposits,omitempty"`
+}
+
+// Microdeposits describes the micro-deposits sent to verify an ACH bank
+// account: two amounts, or one deposit with a descriptor code
+type Microdeposits struct {
+	Type                  string `json:"type"`
+	ArrivalDate           int64  `json:"arrival_date"`
+	HostedVerificationURL string `json:"hosted_verification_url,omitempty"`
+}
+
+// SetupIntentRequest represents a request to start saving a payment method
+type SetupIntentRequest struct {
+	Customer           string            `json:"customer" validate:"required"`
+	Usage              string            `json:"usage,omitempty" validate:"omitempty,oneof=off_session on_session"` // Defaults to off_session
+	PaymentMethodTypes []string          `json:"payment_method_types,omitempty" validate:"omitempty,dive,oneof=card sepa_debit us_bank_account bacs_debit"`
+	VerificationMethod string            `json:"verification_method,omitempty" validate:"omitempty,oneof=automatic instant microdeposits"` // How us_bank_account accounts are verified
+	Metadata           map[string]string `json:"metadata,omitempty"`
+}
+
+// ConfirmSetupIntentRequest confirms a setup intent from the server, for
+// payment methods the frontend created without confirming
+type ConfirmSetupIntentRequest struct {
+	PaymentMethod string             `json:"payment_method_id" validate:"required"`
+	ReturnURL     string             `json:"return_url,omitempty" validate:"omitempty,url"` // Where redirect-based authentication sends the customer back to
+	Mandate       *MandateAcceptance `json:"mandate,omitempty"`                             // Required for bank debits
+}
+
+// CreateSetupIntent creates a setup intent for a customer. The payment
+// method is attached to the customer by Stripe once the intent succeeds.
+func (s *CustomerService) CreateSetupIntent(ctx context.Context, request *SetupIntentRequest) (*SetupIntent, error) {
+	ctx, span := s.tracer.Start(ctx, "CreateSetupIntent")
+	defer span.End()
+
+	if err := s.validator.Struct(request); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	usage := request.Usage
+	if usage == "" {
+		usage = string(stripe.SetupIntentUsageOffSession)
+	}
+
+	params := &stripe.SetupIntentParams{
+		Customer: stripe.String(request.Customer),
+		Usage:    stripe.String(usage),
+	}
+	if len(request.PaymentMethodTypes) > 0 {
+		params.PaymentMethodTypes = stripe.StringSlice(request.PaymentMethodTypes)
+	} else {
+		params.AutomaticPaymentMethods = &stripe.SetupIntentAutomaticPaymentMethodsParams{
+			Enabled: stripe.Bool(true),
+		}
+	}
+	if request.VerificationMethod != "" {
+		params.PaymentMethodOptions = &stripe.SetupIntentPaymentMethodOptionsParams{
+			USBankAccount: &stripe.SetupIntentPaymentMethodOptionsUSBankAccountParams{
+				VerificationMethod: stripe.String(request.VerificationMethod),
+			},
+		}
+	}
+	if len(request.Metadata) > 0 {
+		params.Metadata = request.Metadata
+	}
+	params.Context = ctx
+
+	stripeIntent, err := setupintent.New(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create setup intent: %w", err)
+	}
+
+	return ConvertSetupIntent(stripeIntent), nil
+}
+
+// GetSetupIntent retrieves a setup intent by ID
+func (s *CustomerService) GetSetupIntent(ctx context.Context, setupIntentID string) (*SetupIntent, error) {
+	ctx, span := s.tracer.Start(ctx, "GetSetupIntent")
+	defer span.End()
+
+	if setupIntentID == "" {
+		return nil, fmt.Errorf("setup intent ID cannot be empty")
+	}
+
+	stripeIntent, err := setupintent.Get(setupIntentID, &stripe.SetupIntentParams{Params: stripe.Params{Context: ctx}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve setup intent: %w", err)
+	}
+
+	return ConvertSetupIntent(stripeIntent), nil
+}
+
+// ConfirmSetupIntent confirms a setup intent with a payment method. The
+// intent may then require a customer action, which the frontend completes
+// with the client secret.
+func (s *CustomerService) ConfirmSetupIntent(ctx context.Context, setupIntentID string, request *ConfirmSetupIntentRequest) (*SetupIntent, error) {
+	ctx, span := s.tracer.Start(ctx, "ConfirmSetupIntent")
+	defer span.End()
+
+	if setupIntentID == "" {
+		return nil, fmt.Errorf("setup intent ID cannot be empty")
+	}
+	if err := s.validator.Struct(request); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	params := &stripe.SetupIntentConfirmParams{
+		PaymentMethod: stripe.String(request.PaymentMethod),
+		MandateData:   mandateData(request.Mandate),
+	}
+	if request.ReturnURL != "" {
+		params.ReturnURL = stripe.String(request.ReturnURL)
+	}
+	params.Context = ctx
+
+	stripeIntent, err := setupintent.Confirm(setupIntentID, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to confirm setup intent: %w", err)
+	}
+
+	return ConvertSetupIntent(stripeIntent), nil
+}
+
+// CancelSetupIntent cancels a setup intent that has not succeeded
+func (s *CustomerService) CancelSetupIntent(ctx context.Context, setupIntentID string) (*SetupIntent, error) {
+	ctx, span := s.tracer.Start(ctx, "CancelSetupIntent")
+	defer span.End()
+
+	if setupIntentID == "" {
+		return nil, fmt.Errorf("setup intent ID cannot be empty")
+	}
+
+	stripeIntent, err := setupintent.Cancel(setupIntentID, &stripe.SetupIntentCancelParams{Params: stripe.Params{Context: ctx}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel setup intent: %w", err)
+	}
+
+	return ConvertSetupIntent(stripeIntent), nil
+}
+
+// SetupIntentPaymentMethod returns the payment method a succeeded setup
+// intent saved, attaching it to the intent's customer if Stripe has not
+func (s *CustomerService) SetupIntentPaymentMethod(ctx context.Context, intent *SetupIntent) (*PaymentMethod, error) {
+	ctx, span := s.tracer.Start(ctx, "SetupIntentPaymentMethod")
+	defer span.End()
+
+	if intent.Status != SetupIntentSucceeded || intent.PaymentMethod == "" {
+		return nil, ErrSetupIntentNotSucceeded
+	}
+
+	stripePaymentMethod, err := paymentmethod.Get(intent.PaymentMethod, &stripe.PaymentMethodParams{Params: stripe.Params{Context: ctx}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve payment method: %w", err)
+	}
+
+	if stripePaymentMethod.Customer == nil || stripePaymentMethod.Customer.ID != intent.Customer {
+		stripePaymentMethod, err = paymentmethod.Attach(intent.PaymentMethod, &stripe.PaymentMethodAttachParams{
+			Params:   stripe.Params{Context: ctx},
+			Customer: stripe.String(intent.Customer),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to attach payment method to customer: %w", err)
+		}
+	}
+
+	paymentMethod := ConvertPaymentMethod(stripePaymentMethod)
+	s.mirror.SavePaymentMethod(ctx, paymentMethod)
+
+	return paymentMethod, nil
+}
+
+// ConvertSetupIntent converts a Stripe setup intent to our SetupIntent type
+func ConvertSetupIntent(stripeIntent *stripe.SetupIntent) *SetupIntent {
+	intent := &SetupIntent{
+		ID:                 stripeIntent.ID,
+		ClientSecret:       stripeIntent.ClientSecret,
+		Status:             string(stripeIntent.Status),
+		Usage:              string(stripeIntent.Usage),
+		PaymentMethodTypes: stripeIntent.PaymentMethodTypes,
+		Metadata:           stripeIntent.Metadata,
+		Created:            stripeIntent.Created,
+	}
+
+	if stripeIntent.Customer != nil {
+		intent.Customer = stripeIntent.Customer.ID
+	}
+	if stripeIntent.PaymentMethod != nil {
+		intent.PaymentMethod = stripeIntent.PaymentMethod.ID
+	}
+	if stripeIntent.NextAction != nil {
+		intent.NextActionType = string(stripeIntent.NextAction.Type)
+		if deposits := stripeIntent.NextAction.VerifyWithMicrodeposits; deposits != nil {
+			intent.Microdeposits = &Microdeposits{
+				Type:                  string(deposits.MicrodepositType),
+				ArrivalDate:           deposits.ArrivalDate,
+				HostedVerificationURL: deposits.HostedVerificationURL,
+			}
+		}
+	}
+	if stripeIntent.Mandate != nil {
+		intent.Mandate = stripeIntent.Mandate.ID
+	}
+	if stripeIntent.LastSetupError != nil {
+		intent.LastError = stripeIntent.LastSetupError.Msg
+	}
+
+	return intent
+}
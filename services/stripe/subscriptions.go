@@ -0,0 +1,445 @@
+package stripe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/invoice"
+	"github.com/stripe/stripe-go/v76/subscription"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrNotReactivatable is returned when reactivating a subscription that is
+// not set to cancel at the end of its period
+var ErrNotReactivatable = errors.New("only subscriptions set to cancel at period end can be reactivated")
+
+// SubscriptionService handles Stripe subscription operations
+type SubscriptionService struct {
+	validator           *validator.Validate
+	tracer              trace.Tracer
+	planChangeListeners []PlanChangeListener
+	mirror              Mirror
+}
+
+// NewSubscriptionService creates a new subscription service
+func NewSubscriptionService() *SubscriptionService {
+	return &SubscriptionService{
+		validator: validator.New(),
+		tracer:    otel.Tracer("payments.subscription"),
+		mirror:    noMirror{},
+	}
+}
+
+// Subscription represents a Stripe subscription
+type Subscription struct {
+	ID                 string            `json:"id"`
+	CustomerID         string            `json:"customer_id"`
+	PriceID            string            `json:"price_id,omitempty"`
+	Quantity           int64             `json:"quantity,omitempty"`
+	Status             string            `json:"status"`
+	Paused             bool              `json:"paused"`
+	CancelAtPeriodEnd  bool              `json:"cancel_at_period_end"`
+	CurrentPeriodStart int64             `json:"current_period_start"`
+	CurrentPeriodEnd   int64             `json:"current_period_end"`
+	CollectionMethod   string            `json:"collection_method"`
+	PaymentTerms       string            `json:"payment_terms,omitempty"`
+	DaysUntilDue       int64             `json:"days_until_due,omitempty"`
+	Metadata           map[string]string `json:"metadata,omitempty"`
+	PendingChange      *PlanChange       `json:"pending_change,omitempty"`
+	LatestInvoiceID    string            `json:"latest_invoice_id,omitempty"`
+	Created            int64             `json:"created"`
+}
+
+// SubscriptionPlan represents a recurring Stripe price subscriptions are billed at
+type SubscriptionPlan struct {
+	ID             string            `json:"id"`
+	ProductID      string            `json:"product_id,omitempty"`
+	Nickname       string            `json:"nickname,omitempty"`
+	Amount         int64             `json:"amount"`
+	Currency       string            `json:"currency"`
+	Interval       string            `json:"interval,omitempty"`
+	IntervalCount  int64             `json:"interval_count,omitempty"`
+	UsageType      string            `json:"usage_type,omitempty"`
+	AggregateUsage string            `json:"aggregate_usage,omitempty"`
+	Active         bool              `json:"active"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+	Created        int64             `json:"created"`
+}
+
+// SubscriptionRequest represents a request to subscribe a customer to a price,
+// charged automatically to the customer's default payment method
+type SubscriptionRequest struct {
+	CustomerID string            `json:"customer_id" validate:"required"`
+	PriceID    string            `json:"price_id" validate:"required"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+}
+
+// CreateSubscription subscribes a customer to a price
+func (s *SubscriptionService) CreateSubscription(ctx context.Context, request *SubscriptionRequest) (*Subscription, error) {
+	ctx, span := s.tracer.Start(ctx, "CreateSubscription")
+	defer span.End()
+
+	if err := s.validator.Struct(request); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	params := &stripe.SubscriptionParams{
+		Customer: stripe.String(request.CustomerID),
+		Items: []*stripe.SubscriptionItemsParams{
+			{Price: stripe.String(request.PriceID)},
+		},
+	}
+	if len(request.Metadata) > 0 {
+		params.Metadata = request.Metadata
+	}
+
+	params.Context = ctx
+	stripeSubscription, err := subscription.New(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create subscription: %w", err)
+	}
+
+	sub := ConvertSubscription(stripeSubscription)
+	s.mirror.SaveSubscription(ctx, sub)
+
+	return sub, nil
+}
+
+// GetSubscription retrieves a subscription by ID
+func (s *SubscriptionService) GetSubscription(ctx context.Context, subscriptionID string) (*Subscription, error) {
+	ctx, span := s.tracer.Start(ctx, "GetSubscription")
+	defer span.End()
+
+	if subscriptionID == "" {
+		return nil, fmt.Errorf("subscription ID cannot be empty")
+	}
+
+	stripeSubscription, err := getSubscriptionWithSchedule(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return ConvertSubscription(stripeSubscription), nil
+}
+
+// ListActiveSubscriptions lists the active subscriptions of a customer
+func (s *SubscriptionService) ListActiveSubscriptions(ctx context.Context, customerID string) ([]*Subscription, error) {
+	ctx, span := s.tracer.Start(ctx, "ListActiveSubscriptions")
+	defer span.End()
+
+	if customerID == "" {
+		return nil, fmt.Errorf("customer ID cannot be empty")
+	}
+
+	params := &stripe.SubscriptionListParams{
+		Customer: stripe.String(customerID),
+		Status:   stripe.String(string(stripe.SubscriptionStatusActive)),
+	}
+
+	params.Context = ctx
+	iter := subscription.List(params)
+	var subscriptions []*Subscription
+
+	for iter.Next() {
+		subscriptions = append(subscriptions, ConvertSubscription(iter.Subscription()))
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+// ListSubscriptions lists a page of subscriptions, newest first, optionally
+// of one customer or in one status
+func (s *SubscriptionService) ListSubscriptions(ctx context.Context, customerID, status string, page Page) ([]*Subscription, error) {
+	ctx, span := s.tracer.Start(ctx, "ListSubscriptions")
+	defer span.End()
+
+	params := &stripe.SubscriptionListParams{}
+	if customerID != "" {
+		params.Customer = stripe.String(customerID)
+	}
+	if status != "" {
+		params.Status = stripe.String(status)
+	}
+	page.apply(&params.ListParams)
+
+	params.Context = ctx
+	iter := subscription.List(params)
+	var subscriptions []*Subscription
+
+	for !page.full(len(subscriptions)) && iter.Next() {
+		subscriptions = append(subscriptions, ConvertSubscription(iter.Subscription()))
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+// UpdateSubscription moves a single-price subscription to another price or
+// quantity and replaces its metadata. Empty values are left unchanged.
+func (s *SubscriptionService) UpdateSubscription(ctx context.Context, subscriptionID string, change *SubscriptionChange, metadata map[string]string) (*Subscription, error) {
+	ctx, span := s.tracer.Start(ctx, "UpdateSubscription")
+	defer span.End()
+
+	if subscriptionID == "" {
+		return nil, fmt.Errorf("subscription ID cannot be empty")
+	}
+
+	params := &stripe.SubscriptionParams{Metadata: metadata}
+	if change != nil && (change.PriceID != "" || change.Quantity > 0) {
+		current, err := subscription.Get(subscriptionID, &stripe.SubscriptionParams{Params: stripe.Params{Context: ctx}})
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve subscription: %w", err)
+		}
+		item, err := singleItem(current)
+		if err != nil {
+			return nil, err
+		}
+
+		params.Items = changedItems(item, change)
+		if change.Prorate != nil && !*change.Prorate {
+			params.ProrationBehavior = stripe.String(string(stripe.SubscriptionSchedulePhaseProrationBehaviorNone))
+		}
+		if change.ProrationDate != nil {
+			params.ProrationDate = stripe.Int64(change.ProrationDate.Unix())
+		}
+	}
+
+	params.Context = ctx
+	stripeSubscription, err := subscription.Update(subscriptionID, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update subscription: %w", err)
+	}
+
+	sub := ConvertSubscription(stripeSubscription)
+	s.mirror.SaveSubscription(ctx, sub)
+
+	return sub, nil
+}
+
+// CancelSubscription cancels a subscription, either immediately or at the
+// end of the current period
+func (s *SubscriptionService) CancelSubscription(ctx context.Context, subscriptionID string, atPeriodEnd bool) (*Subscription, error) {
+	ctx, span := s.tracer.Start(ctx, "CancelSubscription")
+	defer span.End()
+
+	if subscriptionID == "" {
+		return nil, fmt.Errorf("subscription ID cannot be empty")
+	}
+
+	var stripeSubscription *stripe.Subscription
+	var err error
+	if atPeriodEnd {
+		stripeSubscription, err = subscription.Update(subscriptionID, &stripe.SubscriptionParams{
+			Params:            stripe.Params{Context: ctx},
+			CancelAtPeriodEnd: stripe.Bool(true),
+		})
+	} else {
+		stripeSubscription, err = subscription.Cancel(subscriptionID, &stripe.SubscriptionCancelParams{Params: stripe.Params{Context: ctx}})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel subscription: %w", err)
+	}
+
+	sub := ConvertSubscription(stripeSubscription)
+	s.mirror.SaveSubscription(ctx, sub)
+
+	return sub, nil
+}
+
+// ReactivateSubscription keeps a subscription set to cancel at period end
+// running. Subscriptions that already ended cannot be reactivated.
+func (s *SubscriptionService) ReactivateSubscription(ctx context.Context, subscriptionID string) (*Subscription, error) {
+	ctx, span := s.tracer.Start(ctx, "ReactivateSubscription")
+	defer span.End()
+
+	if subscriptionID == "" {
+		return nil, fmt.Errorf("subscription ID cannot be empty")
+	}
+
+	current, err := subscription.Get(subscriptionID, &stripe.SubscriptionParams{Params: stripe.Params{Context: ctx}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve subscription: %w", err)
+	}
+	if !current.CancelAtPeriodEnd || current.Status == stripe.SubscriptionStatusCanceled {
+		return nil, ErrNotReactivatable
+	}
+
+	stripeSubscription, err := subscription.Update(subscriptionID, &stripe.SubscriptionParams{
+		Params:            stripe.Params{Context: ctx},
+		CancelAtPeriodEnd: stripe.Bool(false),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to reactivate subscription: %w", err)
+	}
+
+	sub := ConvertSubscription(stripeSubscription)
+	s.mirror.SaveSubscription(ctx, sub)
+
+	return sub, nil
+}
+
+// PauseSubscription pauses payment collection for a subscription.
+// Invoices generated while paused are voided.
+func (s *SubscriptionService) PauseSubscription(ctx context.Context, subscriptionID string) (*Subscription, error) {
+	ctx, span := s.tracer.Start(ctx, "PauseSubscription")
+	defer span.End()
+
+	if subscriptionID == "" {
+		return nil, fmt.Errorf("subscription ID cannot be empty")
+	}
+
+	params := &stripe.SubscriptionParams{
+		PauseCollection: &stripe.SubscriptionPauseCollectionParams{
+			Behavior: stripe.String(string(stripe.SubscriptionPauseCollectionBehaviorVoid)),
+		},
+	}
+
+	params.Context = ctx
+	stripeSubscription, err := subscription.Update(subscriptionID, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pause subscription: %w", err)
+	}
+
+	sub := ConvertSubscription(stripeSubscription)
+	s.mirror.SaveSubscription(ctx, sub)
+
+	return sub, nil
+}
+
+// UnpauseSubscription resumes payment collection for a paused subscription
+func (s *SubscriptionService) UnpauseSubscription(ctx context.Context, subscriptionID string) (*Subscription, error) {
+	ctx, span := s.tracer.Start(ctx, "UnpauseSubscription")
+	defer span.End()
+
+	if subscriptionID == "" {
+		return nil, fmt.Errorf("subscription ID cannot be empty")
+	}
+
+	// Stripe clears pause_collection when it is sent as an empty value
+	params := &stripe.SubscriptionParams{}
+	params.AddExtra("pause_collection", "")
+
+	params.Context = ctx
+	stripeSubscription, err := subscription.Update(subscriptionID, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpause subscription: %w", err)
+	}
+
+	sub := ConvertSubscription(stripeSubscription)
+	s.mirror.SaveSubscription(ctx, sub)
+
+	return sub, nil
+}
+
+// InvoicePlanName returns the name of the subscription plan an invoice bills
+// for, or "" if the invoice has no subscription line
+func (s *SubscriptionService) InvoicePlanName(ctx context.Context, invoiceID string) (string, error) {
+	ctx, span := s.tracer.Start(ctx, "InvoicePlanName")
+	defer span.End()
+
+	if invoiceID == "" {
+		return "", fmt.Errorf("invoice ID cannot be empty")
+	}
+
+	params := &stripe.InvoiceParams{}
+	params.AddExpand("lines.data.price.product")
+
+	params.Context = ctx
+	stripeInvoice, err := invoice.Get(invoiceID, params)
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve invoice: %w", err)
+	}
+
+	if stripeInvoice.Lines == nil {
+		return "", nil
+	}
+
+	for _, line := range stripeInvoice.Lines.Data {
+		if line.Price == nil || line.Subscription == nil {
+			continue
+		}
+		if line.Price.Nickname != "" {
+			return line.Price.Nickname, nil
+		}
+		if line.Price.Product != nil && line.Price.Product.Name != "" {
+			return line.Price.Product.Name, nil
+		}
+		return line.Price.ID, nil
+	}
+
+	return "", nil
+}
+
+// ConvertSubscription converts a Stripe subscription to our Subscription type
+func ConvertSubscription(stripeSubscription *stripe.Subscription) *Subscription {
+	sub := &Subscription{
+		ID:                 stripeSubscription.ID,
+		Status:             string(stripeSubscription.Status),
+		Paused:             stripeSubscription.PauseCollection != nil,
+		CancelAtPeriodEnd:  stripeSubscription.CancelAtPeriodEnd,
+		CurrentPeriodStart: stripeSubscription.CurrentPeriodStart,
+		CurrentPeriodEnd:   stripeSubscription.CurrentPeriodEnd,
+		CollectionMethod:   string(stripeSubscription.CollectionMethod),
+		DaysUntilDue:       stripeSubscription.DaysUntilDue,
+		Metadata:           stripeSubscription.Metadata,
+		Created:            stripeSubscription.Created,
+	}
+
+	if stripeSubscription.Customer != nil {
+		sub.CustomerID = stripeSubscription.Customer.ID
+	}
+	if stripeSubscription.LatestInvoice != nil {
+		sub.LatestInvoiceID = stripeSubscription.LatestInvoice.ID
+	}
+
+	if stripeSubscription.Items != nil && len(stripeSubscription.Items.Data) > 0 && stripeSubscription.Items.Data[0].Price != nil {
+		sub.PriceID = stripeSubscription.Items.Data[0].Price.ID
+		sub.Quantity = stripeSubscription.Items.Data[0].Quantity
+	}
+
+	if stripeSubscription.CollectionMethod == stripe.SubscriptionCollectionMethodSendInvoice {
+		sub.PaymentTerms = paymentTerms(stripeSubscription.DaysUntilDue)
+	}
+
+	// Only set when the schedule was expanded
+	sub.PendingChange = pendingPlanChange(stripeSubscription)
+
+	return sub
+}
+
+// ConvertPlan converts a Stripe price to our SubscriptionPlan type
+func ConvertPlan(stripePrice *stripe.Price) *SubscriptionPlan {
+	plan := &SubscriptionPlan{
+		ID:       stripePrice.ID,
+		Nickname: stripePrice.Nickname,
+		Amount:   stripePrice.UnitAmount,
+		Currency: string(stripePrice.Currency),
+		Active:   stripePrice.Active,
+		Metadata: stripePrice.Metadata,
+		Created:  stripePrice.Created,
+	}
+
+	if stripePrice.Product != nil {
+		plan.ProductID = stripePrice.Product.ID
+	}
+
+	if stripePrice.Recurring != nil {
+		plan.Interval = string(stripePrice.Recurring.Interval)
+		plan.IntervalCount = stripePrice.Recurring.IntervalCount
+		plan.UsageType = string(stripePrice.Recurring.UsageType)
+		plan.AggregateUsage = string(stripePrice.Recurring.AggregateUsage)
+	}
+
+	return plan
+}
@@ -0,0 +1,162 @@
+package stripe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/invoice"
+	"github.com/stripe/stripe-go/v76/subscription"
+)
+
+// Payment terms for invoiced (send_invoice) subscriptions
+const (
+	TermsNet15 = "net_15"
+	TermsNet30 = "net_30"
+	TermsNet60 = "net_60"
+)
+
+// paymentTermDays maps payment terms to days until an invoice is due
+var paymentTermDays = map[string]int64{
+	TermsNet15: 15,
+	TermsNet30: 30,
+	TermsNet60: 60,
+}
+
+// ErrInvalidPaymentTerms is returned for unsupported payment terms
+var ErrInvalidPaymentTerms = errors.New("payment terms must be one of net_15, net_30, net_60")
+
+// PaymentTermDays returns the days until due for payment terms
+func PaymentTermDays(terms string) (int64, error) {
+	days, ok := paymentTermDays[terms]
+	if !ok {
+		return 0, ErrInvalidPaymentTerms
+	}
+	return days, nil
+}
+
+// paymentTerms returns the payment terms matching days until due, or "" for
+// custom terms
+func paymentTerms(days int64) string {
+	for terms, termDays := range paymentTermDays {
+		if termDays == days {
+			return terms
+		}
+	}
+	return ""
+}
+
+// InvoicedSubscriptionRequest creates a subscription that is billed by
+// emailing invoices with payment terms instead of charging a card
+type InvoicedSubscriptionRequest struct {
+	CustomerID string            `json:"customer_id" validate:"required"`
+	PriceID    string            `json:"price_id" validate:"required"`
+	Terms      string            `json:"terms" validate:"required"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+}
+
+// ValidateInvoicedSubscription validates an invoiced subscription request,
+// returning the days until its invoices are due
+func (s *SubscriptionService) ValidateInvoicedSubscription(request *InvoicedSubscriptionRequest) (int64, error) {
+	if err := s.validator.Struct(request); err != nil {
+		return 0, fmt.Errorf("validation failed: %w", err)
+	}
+
+	return PaymentTermDays(request.Terms)
+}
+
+// CreateInvoicedSubscription creates a send_invoice subscription whose
+// invoices are due after the requested payment terms
+func (s *SubscriptionService) CreateInvoicedSubscription(ctx context.Context, request *InvoicedSubscriptionRequest) (*Subscription, error) {
+	ctx, span := s.tracer.Start(ctx, "CreateInvoicedSubscription")
+	defer span.End()
+
+	days, err := s.ValidateInvoicedSubscription(request)
+	if err != nil {
+		return nil, err
+	}
+
+	params := &stripe.SubscriptionParams{
+		Customer: stripe.String(request.CustomerID),
+		Items: []*stripe.SubscriptionItemsParams{
+			{Price: stripe.String(request.PriceID)},
+		},
+		CollectionMethod: stripe.String(string(stripe.SubscriptionCollectionMethodSendInvoice)),
+		DaysUntilDue:     stripe.Int64(days),
+	}
+	if len(request.Metadata) > 0 {
+		params.Metadata = request.Metadata
+	}
+
+	params.Context = ctx
+	stripeSubscription, err := subscription.New(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create subscription: %w", err)
+	}
+
+	sub := ConvertSubscription(stripeSubscription)
+	s.mirror.SaveSubscription(ctx, sub)
+
+	return sub, nil
+}
+
+// SetPaymentTerms changes the payment terms of an invoiced subscription.
+// Invoices already finalized keep their due dates.
+func (s *SubscriptionService) SetPaymentTerms(ctx context.Context, subscriptionID, terms string) (*Subscription, error) {
+	ctx, span := s.tracer.Start(ctx, "SetPaymentTerms")
+	defer span.End()
+
+	if subscriptionID == "" {
+		return nil, fmt.Errorf("subscription ID cannot be empty")
+	}
+
+	days, err := PaymentTermDays(terms)
+	if err != nil {
+		return nil, err
+	}
+
+	params := &stripe.SubscriptionParams{
+		CollectionMethod: stripe.String(string(stripe.SubscriptionCollectionMethodSendInvoice)),
+		DaysUntilDue:     stripe.Int64(days),
+	}
+
+	params.Context = ctx
+	stripeSubscription, err := subscription.Update(subscriptionID, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update payment terms: %w", err)
+	}
+
+	sub := ConvertSubscription(stripeSubscription)
+	s.mirror.SaveSubscription(ctx, sub)
+
+	return sub, nil
+}
+
+// SetInvoiceCustomFields replaces the custom fields printed on a draft invoice
+func (s *SubscriptionService) SetInvoiceCustomFields(ctx context.Context, invoiceID string, fields []InvoiceCustomField) (*Invoice, error) {
+	ctx, span := s.tracer.Start(ctx, "SetInvoiceCustomFields")
+	defer span.End()
+
+	if invoiceID == "" {
+		return nil, fmt.Errorf("invoice ID cannot be empty")
+	}
+
+	params := &stripe.InvoiceParams{
+		CustomFields: make([]*stripe.InvoiceCustomFieldParams, len(fields)),
+	}
+	for i, field := range fields {
+		params.CustomFields[i] = &stripe.InvoiceCustomFieldParams{
+			Name:  stripe.String(field.Name),
+			Value: stripe.String(field.Value),
+		}
+	}
+
+	params.Context = ctx
+	stripeInvoice, err := invoice.Update(invoiceID, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set invoice custom fields: %w", err)
+	}
+
+	return ConvertInvoice(stripeInvoice), nil
+}
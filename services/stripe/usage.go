@@ -0,0 +1,124 @@
+package stripe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/subscription"
+	"github.com/stripe/stripe-go/v76/usagerecord"
+)
+
+// Usage record actions. Increments add to the usage at a timestamp, sets
+// replace it.
+const (
+	UsageActionIncrement = "increment"
+	UsageActionSet       = "set"
+)
+
+// ErrNotMetered is returned for subscriptions without a metered price
+var ErrNotMetered = errors.New("subscription has no metered price")
+
+// MeteredItem is the subscription item billed at a metered price
+type MeteredItem struct {
+	ID             string `json:"id"`
+	SubscriptionID string `json:"subscription_id"`
+	PriceID        string `json:"price_id"`
+	// AggregateUsage is how Stripe totals the period's usage records: sum,
+	// max, last_during_period or last_ever
+	AggregateUsage     string `json:"aggregate_usage"`
+	CurrentPeriodStart int64  `json:"current_period_start"`
+	CurrentPeriodEnd   int64  `json:"current_period_end"`
+}
+
+// UsageRecordRequest reports usage of a metered subscription item
+type UsageRecordRequest struct {
+	SubscriptionItemID string `validate:"required"`
+	Quantity           int64  `validate:"gte=0"`
+	Timestamp          int64  `validate:"required"`
+	Action             string `validate:"required,oneof=increment set"`
+	IdempotencyKey     string // Set so retried reports count once
+}
+
+// UsageRecord is usage recorded at the provider
+type UsageRecord struct {
+	ID                 string `json:"id"`
+	SubscriptionItemID string `json:"subscription_item_id"`
+	Quantity           int64  `json:"quantity"`
+	Timestamp          int64  `json:"timestamp"`
+}
+
+// MeteredItem retrieves the metered item of a subscription
+func (s *SubscriptionService) MeteredItem(ctx context.Context, subscriptionID string) (*MeteredItem, error) {
+	ctx, span := s.tracer.Start(ctx, "MeteredItem")
+	defer span.End()
+
+	if subscriptionID == "" {
+		return nil, fmt.Errorf("subscription ID cannot be empty")
+	}
+
+	stripeSubscription, err := subscription.Get(subscriptionID, &stripe.SubscriptionParams{Params: stripe.Params{Context: ctx}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve subscription: %w", err)
+	}
+
+	return ConvertMeteredItem(stripeSubscription)
+}
+
+// ReportUsage records usage of a metered subscription item
+func (s *SubscriptionService) ReportUsage(ctx context.Context, request *UsageRecordRequest) (*UsageRecord, error) {
+	ctx, span := s.tracer.Start(ctx, "ReportUsage")
+	defer span.End()
+
+	if err := s.validator.Struct(request); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	params := &stripe.UsageRecordParams{
+		SubscriptionItem: stripe.String(request.SubscriptionItemID),
+		Quantity:         stripe.Int64(request.Quantity),
+		Timestamp:        stripe.Int64(request.Timestamp),
+		Action:           stripe.String(request.Action),
+	}
+	if request.IdempotencyKey != "" {
+		params.SetIdempotencyKey(request.IdempotencyKey)
+	}
+
+	params.Context = ctx
+	stripeRecord, err := usagerecord.New(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to report usage: %w", err)
+	}
+
+	return &UsageRecord{
+		ID:                 stripeRecord.ID,
+		SubscriptionItemID: stripeRecord.SubscriptionItem,
+		Quantity:           stripeRecord.Quantity,
+		Timestamp:          stripeRecord.Timestamp,
+	}, nil
+}
+
+// ConvertMeteredItem finds the item of a Stripe subscription billed at a
+// metered price
+func ConvertMeteredItem(stripeSubscription *stripe.Subscription) (*MeteredItem, error) {
+	if stripeSubscription.Items == nil {
+		return nil, ErrNotMetered
+	}
+
+	for _, item := range stripeSubscription.Items.Data {
+		if item.Price == nil || item.Price.Recurring == nil || item.Price.Recurring.UsageType != stripe.PriceRecurringUsageTypeMetered {
+			continue
+		}
+		return &MeteredItem{
+			ID:                 item.ID,
+			SubscriptionID:     stripeSubscription.ID,
+			PriceID:            item.Price.ID,
+			AggregateUsage:     string(item.Price.Recurring.AggregateUsage),
+			CurrentPeriodStart: stripeSubscription.CurrentPeriodStart,
+			CurrentPeriodEnd:   stripeSubscription.CurrentPeriodEnd,
+		}, nil
+	}
+
+	return nil, ErrNotMetered
+}
@@ -0,0 +1,297 @@
+package stripe
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/paymentmethod"
+	"github.com/stripe/stripe-go/v76/paymentmethoddomain"
+	"github.com/stripe/stripe-go/v76/token"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Wallets a card payment can be made with. A wallet charge is a card charge
+// authorized with a device PAN, so its credential type is dpan.
+const (
+	WalletApplePay  = "apple_pay"
+	WalletGooglePay = "google_pay"
+)
+
+var (
+	// ErrUnsupportedWallet is returned for a payment token from a wallet other than Apple Pay or Google Pay
+	ErrUnsupportedWallet = errors.New("wallet must be apple_pay or google_pay")
+	// ErrInvalidWalletToken is returned for a payment token that can't be decoded
+	ErrInvalidWalletToken = errors.New("invalid wallet payment token")
+)
+
+// WalletDomain is a domain registered to show Apple Pay and Google Pay
+// buttons. Apple Pay only works on domains it has verified.
+type WalletDomain struct {
+	ID              string `json:"id"`
+	DomainName      string `json:"domain_name"`
+	Enabled         bool   `json:"enabled"`
+	ApplePayStatus  string `json:"apple_pay_status"`
+	ApplePayError   string `json:"apple_pay_error,omitempty"`
+	GooglePayStatus string `json:"google_pay_status"`
+	GooglePayError  string `json:"google_pay_error,omitempty"`
+	Created         int64  `json:"created"`
+}
+
+// WalletToken is the payment token a wallet returns when the customer
+// authorizes a payment: Apple Pay's PKPaymentToken or Google Pay's
+// PaymentData, passed on as the client received it
+type WalletToken struct {
+	Wallet       string          `json:"wallet" validate:"required"`
+	PaymentToken json.RawMessage `json:"payment_token" validate:"required"`
+}
+
+// applePayToken is the part of an Apple Pay PKPaymentToken Stripe needs
+type applePayToken struct {
+	PaymentData   json.RawMessage `json:"paymentData"`
+	PaymentMethod struct {
+		DisplayName string `json:"displayName"`
+		Network     string `json:"network"`
+	} `json:"paymentMethod"`
+	TransactionIdentifier string `json:"transactionIdentifier"`
+}
+
+// googlePayData is the part of a Google Pay PaymentData object Stripe needs.
+// With Stripe as the gateway, the tokenization token is a JSON encoded
+// Stripe card token.
+type googlePayData struct {
+	PaymentMethodData struct {
+		TokenizationData struct {
+			Type  string `json:"type"`
+			Token string `json:"token"`
+		} `json:"tokenizationData"`
+	} `json:"paymentMethodData"`
+}
+
+// WalletService registers wallet domains and turns wallet payment tokens
+// into payment methods that can be charged
+type WalletService struct {
+	validator *validator.Validate
+	tracer    trace.Tracer
+}
+
+// NewWalletService creates a new wallet service
+func NewWalletService() *WalletService {
+	return &WalletService{
+		validator: validator.New(),
+		tracer:    otel.Tracer("payments.wallets"),
+	}
+}
+
+// RegisterDomain registers a domain for Apple Pay and Google Pay. Apple Pay
+// verifies the domain straight away, so the domain association file must
+// already be served from it.
+func (s *WalletService) RegisterDomain(ctx context.Context, domainName string) (*WalletDomain, error) {
+	ctx, span := s.tracer.Start(ctx, "RegisterDomain")
+	defer span.End()
+
+	if domainName == "" {
+		return nil, fmt.Errorf("domain name cannot be empty")
+	}
+
+	params := &stripe.PaymentMethodDomainParams{
+		DomainName: stripe.String(strings.ToLower(domainName)),
+		Enabled:    stripe.Bool(true),
+	}
+	params.Context = ctx
+
+	domain, err := paymentmethoddomain.New(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register wallet domain: %w", err)
+	}
+
+	return ConvertWalletDomain(domain), nil
+}
+
+// ValidateDomain asks Apple Pay to verify a registered domain again, e.g.
+// after the domain association file was put in place
+func (s *WalletService) ValidateDomain(ctx context.Context, domainID string) (*WalletDomain, error) {
+	ctx, span := s.tracer.Start(ctx, "ValidateDomain")
+	defer span.End()
+
+	if domainID == "" {
+		return nil, fmt.Errorf("domain ID cannot be empty")
+	}
+
+	params := &stripe.PaymentMethodDomainValidateParams{}
+	params.Context = ctx
+
+	domain, err := paymentmethoddomain.Validate(domainID, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate wallet domain: %w", err)
+	}
+
+	return ConvertWalletDomain(domain), nil
+}
+
+// ListDomains lists the registered wallet domains
+func (s *WalletService) ListDomains(ctx context.Context, page Page) ([]*WalletDomain, error) {
+	ctx, span := s.tracer.Start(ctx, "ListDomains")
+	defer span.End()
+
+	params := &stripe.PaymentMethodDomainListParams{}
+	page.apply(&params.ListParams)
+	params.Context = ctx
+
+	iter := paymentmethoddomain.List(params)
+	var domains []*WalletDomain
+
+	for !page.full(len(domains)) && iter.Next() {
+		domains = append(domains, ConvertWalletDomain(iter.PaymentMethodDomain()))
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list wallet domains: %w", err)
+	}
+
+	return domains, nil
+}
+
+// PaymentMethodForToken decodes a wallet payment token into a card
+// PaymentMethod that CreateCharge accepts
+func (s *WalletService) PaymentMethodForToken(ctx context.Context, walletToken *WalletToken) (string, error) {
+	ctx, span := s.tracer.Start(ctx, "PaymentMethodForToken")
+	defer span.End()
+
+	if err := s.validator.Struct(walletToken); err != nil {
+		return "", fmt.Errorf("validation failed: %w", err)
+	}
+
+	var cardToken string
+	var err error
+	switch walletToken.Wallet {
+	case WalletApplePay:
+		cardToken, err = s.applePayCardToken(ctx, walletToken.PaymentToken)
+	case WalletGooglePay:
+		cardToken, err = GooglePayCardToken(walletToken.PaymentToken)
+	default:
+		return "", ErrUnsupportedWallet
+	}
+	if err != nil {
+		return "", err
+	}
+
+	stripePaymentMethod, err := paymentmethod.New(&stripe.PaymentMethodParams{
+		Params: stripe.Params{Context: ctx},
+		Type:   stripe.String(string(stripe.PaymentMethodTypeCard)),
+		Card: &stripe.PaymentMethodCardParams{
+			Token: stripe.String(cardToken),
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to convert wallet token to a payment method: %w", err)
+	}
+
+	return stripePaymentMethod.ID, nil
+}
+
+// applePayCardToken exchanges an Apple Pay payment token for a Stripe card
+// token. Stripe decrypts the payment data with the merchant's Apple Pay
+// certificate.
+func (s *WalletService) applePayCardToken(ctx context.Context, raw json.RawMessage) (string, error) {
+	params, err := ApplePayTokenParams(raw)
+	if err != nil {
+		return "", err
+	}
+	params.Context = ctx
+
+	stripeToken, err := token.New(params)
+	if err != nil {
+		return "", fmt.Errorf("failed to create Apple Pay token: %w", err)
+	}
+
+	return stripeToken.ID, nil
+}
+
+// ApplePayTokenParams builds the Stripe token params of an Apple Pay
+// PKPaymentToken
+func ApplePayTokenParams(raw json.RawMessage) (*stripe.TokenParams, error) {
+	var applePay applePayToken
+	if err := json.Unmarshal(raw, &applePay); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWalletToken, err)
+	}
+	if len(applePay.PaymentData) == 0 || applePay.TransactionIdentifier == "" {
+		return nil, fmt.Errorf("%w: Apple Pay tokens need paymentData and transactionIdentifier", ErrInvalidWalletToken)
+	}
+
+	params := &stripe.TokenParams{}
+	params.AddExtra("pk_token", string(applePay.PaymentData))
+	params.AddExtra("pk_token_transaction_id", applePay.TransactionIdentifier)
+	if applePay.PaymentMethod.DisplayName != "" {
+		params.AddExtra("pk_token_instrument_name", applePay.PaymentMethod.DisplayName)
+	}
+	if applePay.PaymentMethod.Network != "" {
+		params.AddExtra("pk_token_payment_network", applePay.PaymentMethod.Network)
+	}
+
+	return params, nil
+}
+
+// GooglePayCardToken extracts the Stripe card token from Google Pay
+// PaymentData tokenized with Stripe as the gateway
+func GooglePayCardToken(raw json.RawMessage) (string, error) {
+	var googlePay googlePayData
+	if err := json.Unmarshal(raw, &googlePay); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidWalletToken, err)
+	}
+
+	tokenization := googlePay.PaymentMethodData.TokenizationData
+	if tokenization.Type != "PAYMENT_GATEWAY" {
+		return "", fmt.Errorf("%w: Google Pay must tokenize with Stripe as the payment gateway", ErrInvalidWalletToken)
+	}
+
+	var cardToken struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal([]byte(tokenization.Token), &cardToken); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidWalletToken, err)
+	}
+	if !strings.HasPrefix(cardToken.ID, "tok_") {
+		return "", fmt.Errorf("%w: Google Pay token is not a Stripe card token", ErrInvalidWalletToken)
+	}
+
+	return cardToken.ID, nil
+}
+
+// ConvertWalletDomain converts a Stripe payment method domain to our WalletDomain type
+func ConvertWalletDomain(domain *stripe.PaymentMethodDomain) *WalletDomain {
+	walletDomain := &WalletDomain{
+		ID:         domain.ID,
+		DomainName: domain.DomainName,
+		Enabled:    domain.Enabled,
+		Created:    domain.Created,
+	}
+
+	if domain.ApplePay != nil {
+		walletDomain.ApplePayStatus = string(domain.ApplePay.Status)
+		if domain.ApplePay.StatusDetails != nil {
+			walletDomain.ApplePayError = domain.ApplePay.StatusDetails.ErrorMessage
+		}
+	}
+	if domain.GooglePay != nil {
+		walletDomain.GooglePayStatus = string(domain.GooglePay.Status)
+		if domain.GooglePay.StatusDetails != nil {
+			walletDomain.GooglePayError = domain.GooglePay.StatusDetails.ErrorMessage
+		}
+	}
+
+	return walletDomain
+}
+
+// cardWallet returns the wallet a card payment method was added with, if any
+func cardWallet(card *stripe.PaymentMethodCard) string {
+	if card == nil || card.Wallet == nil {
+		return ""
+	}
+	return string(card.Wallet.Type)
+}
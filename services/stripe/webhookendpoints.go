@@ -0,0 +1,96 @@
+package stripe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/webhookendpoint"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WebhookEndpoint represents a Stripe webhook endpoint. The signing secret
+// is only returned when the endpoint is created.
+type WebhookEndpoint struct {
+	ID            string   `json:"id"`
+	URL           string   `json:"url"`
+	EnabledEvents []string `json:"enabled_events"`
+	Status        string   `json:"status"`
+	Secret        string   `json:"-"`
+}
+
+// WebhookEndpointService manages the endpoints Stripe delivers webhooks to
+type WebhookEndpointService struct {
+	tracer trace.Tracer
+}
+
+// NewWebhookEndpointService creates a new webhook endpoint service
+func NewWebhookEndpointService() *WebhookEndpointService {
+	return &WebhookEndpointService{
+		tracer: otel.Tracer("payments.webhookendpoint"),
+	}
+}
+
+// CloneWebhookEndpoint creates an endpoint with the same URL, events and API
+// version as an existing one. Stripe signs its deliveries with a new secret.
+func (s *WebhookEndpointService) CloneWebhookEndpoint(ctx context.Context, endpointID string) (*WebhookEndpoint, error) {
+	ctx, span := s.tracer.Start(ctx, "CloneWebhookEndpoint")
+	defer span.End()
+
+	existing, err := webhookendpoint.Get(endpointID, &stripe.WebhookEndpointParams{Params: stripe.Params{Context: ctx}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook endpoint %s: %w", endpointID, err)
+	}
+
+	params := &stripe.WebhookEndpointParams{
+		URL:           stripe.String(existing.URL),
+		EnabledEvents: stripe.StringSlice(existing.EnabledEvents),
+		Description:   stripe.String(existing.Description),
+		Metadata:      existing.Metadata,
+	}
+	params.Context = ctx
+	if existing.APIVersion != "" {
+		params.APIVersion = stripe.String(existing.APIVersion)
+	}
+	if existing.Application != "" {
+		params.Connect = stripe.Bool(true)
+	}
+
+	endpoint, err := webhookendpoint.New(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook endpoint: %w", err)
+	}
+
+	return convertWebhookEndpoint(endpoint), nil
+}
+
+// DeleteWebhookEndpoint deletes an endpoint. Deleting an endpoint that no
+// longer exists succeeds.
+func (s *WebhookEndpointService) DeleteWebhookEndpoint(ctx context.Context, endpointID string) error {
+	ctx, span := s.tracer.Start(ctx, "DeleteWebhookEndpoint")
+	defer span.End()
+
+	_, err := webhookendpoint.Del(endpointID, &stripe.WebhookEndpointParams{Params: stripe.Params{Context: ctx}})
+	var stripeErr *stripe.Error
+	if errors.As(err, &stripeErr) && stripeErr.Code == stripe.ErrorCodeResourceMissing {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook endpoint %s: %w", endpointID, err)
+	}
+
+	return nil
+}
+
+// convertWebhookEndpoint converts a Stripe webhook endpoint
+func convertWebhookEndpoint(endpoint *stripe.WebhookEndpoint) *WebhookEndpoint {
+	return &WebhookEndpoint{
+		ID:            endpoint.ID,
+		URL:           endpoint.URL,
+		EnabledEvents: endpoint.EnabledEvents,
+		Status:        endpoint.Status,
+		Secret:        endpoint.Secret,
+	}
+}
@@ -0,0 +1,169 @@
+package stripe
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/webhook"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WebhookHandler processes a single verified Stripe event
+type WebhookHandler func(ctx context.Context, event stripe.Event) error
+
+// Event sources
+const (
+	EventSourceWebhook    = "webhook"
+	EventSourceCatchUp    = "catchup"
+	EventSourceDeadLetter = "dead_letter"
+)
+
+// EventLog records processed events so redeliveries and catch-up runs are
+// idempotent. Events are recorded with their full payload.
+type EventLog interface {
+	IsEventProcessed(ctx context.Context, eventID string) (bool, error)
+	RecordProcessedEvent(ctx context.Context, event stripe.Event, source string) error
+	LastProcessedEventTime(ctx context.Context) (int64, error)
+}
+
+// SecretSource supplies the secrets webhook signatures are verified against,
+// such as both the old and the new secret while a secret is rotated
+type SecretSource interface {
+	WebhookSecrets() []string
+}
+
+// WebhookService verifies incoming Stripe webhooks and dispatches them to handlers
+type WebhookService struct {
+	secret   string
+	source   SecretSource
+	handlers map[stripe.EventType][]WebhookHandler
+	events   EventLog
+	tracer   trace.Tracer
+}
+
+// NewWebhookService creates a new webhook service using the given signing secret
+func NewWebhookService(secret string) *WebhookService {
+	return &WebhookService{
+		secret:   secret,
+		handlers: make(map[stripe.EventType][]WebhookHandler),
+		tracer:   otel.Tracer("payments.webhook"),
+	}
+}
+
+// On registers a handler for an event type. Handlers run in registration order.
+func (s *WebhookService) On(eventType stripe.EventType, handler WebhookHandler) {
+	s.handlers[eventType] = append(s.handlers[eventType], handler)
+}
+
+// UseEventLog enables deduplication of processed events
+func (s *WebhookService) UseEventLog(events EventLog) {
+	s.events = events
+}
+
+// UseSecretSource verifies signatures against the source's secrets instead
+// of the secret the service was created with
+func (s *WebhookService) UseSecretSource(source SecretSource) {
+	s.source = source
+}
+
+// ConstructEvent verifies the Stripe-Signature header and parses the event payload
+func (s *WebhookService) ConstructEvent(payload []byte, signature string) (stripe.Event, error) {
+	event, _, err := s.VerifyEvent(payload, signature)
+	return event, err
+}
+
+// VerifyEvent verifies the Stripe-Signature header against each accepted
+// secret and parses the event payload. It also returns the secret the
+// signature matched.
+func (s *WebhookService) VerifyEvent(payload []byte, signature string) (stripe.Event, string, error) {
+	secrets := []string{s.secret}
+	if s.source != nil {
+		secrets = s.source.WebhookSecrets()
+	}
+
+	var err error
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+
+		var event stripe.Event
+		event, err = webhook.ConstructEventWithOptions(payload, signature, secret, webhook.ConstructEventOptions{
+			IgnoreAPIVersionMismatch: true,
+		})
+		if err == nil {
+			return event, secret, nil
+		}
+	}
+	if err == nil {
+		return stripe.Event{}, "", fmt.Errorf("webhook secret is not configured")
+	}
+
+	return stripe.Event{}, "", fmt.Errorf("failed to verify webhook signature: %w", err)
+}
+
+// Dispatch runs all handlers registered for the event's type
+func (s *WebhookService) Dispatch(ctx context.Context, event stripe.Event) error {
+	ctx, span := s.tracer.Start(ctx, "DispatchWebhook")
+	defer span.End()
+
+	for _, handler := range s.handlers[event.Type] {
+		if err := handler(ctx, event); err != nil {
+			return fmt.Errorf("failed to handle %s event %s: %w", event.Type, event.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// Process dispatches an event unless it has already been processed, then
+// records it. Events that fail are not recorded so they can be retried.
+func (s *WebhookService) Process(ctx context.Context, event stripe.Event, source string) (bool, error) {
+	ctx, span := s.tracer.Start(ctx, "ProcessWebhook")
+	defer span.End()
+
+	if s.events != nil {
+		processed, err := s.events.IsEventProcessed(ctx, event.ID)
+		if err != nil {
+			return false, fmt.Errorf("failed to check event %s: %w", event.ID, err)
+		}
+		if processed {
+			return false, nil
+		}
+	}
+
+	if err := s.Dispatch(ctx, event); err != nil {
+		return false, err
+	}
+
+	if s.events != nil {
+		if err := s.events.RecordProcessedEvent(ctx, event, source); err != nil {
+			return true, fmt.Errorf("failed to record event %s: %w", event.ID, err)
+		}
+	}
+
+	return true, nil
+}
+
+// Replay processes a stored event payload again, such as one kept in the
+// dead-letter queue after it failed
+func (s *WebhookService) Replay(ctx context.Context, payload []byte) (bool, error) {
+	var event stripe.Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return false, fmt.Errorf("failed to decode event: %w", err)
+	}
+
+	return s.Process(ctx, event, EventSourceDeadLetter)
+}
+
+// EventTypes returns the event types that have registered handlers
+func (s *WebhookService) EventTypes() []stripe.EventType {
+	types := make([]stripe.EventType, 0, len(s.handlers))
+	for eventType := range s.handlers {
+		types = append(types, eventType)
+	}
+	return types
+}
@@ -0,0 +1,1107 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"apis/payments/services/egress"
+	"apis/payments/services/stripe"
+)
+
+// stripeDefaultListLimit is the page size used when a list request sets no limit
+const stripeDefaultListLimit = 10
+
+// stripePaymentMethodTokenKey is the metadata key carrying the Stripe.js card
+// token a payment method is created from
+const stripePaymentMethodTokenKey = "token"
+
+// StripeGateway implements the PaymentGateway, DisputeManager, InvoiceGateway,
+// ConnectGateway and PaymentLinkGateway interfaces for Stripe on top of the services in the stripe package, so the gateway
+// and the API share one Stripe client, one SDK version and one set of
+// charge guards and mirrors.
+type StripeGateway struct {
+	customers     *stripe.CustomerService
+	charges       *stripe.ChargeService
+	refunds       *stripe.RefundService
+	subscriptions *stripe.SubscriptionService
+	disputes      *stripe.DisputeService
+	invoices      *stripe.InvoiceService
+	connect       *stripe.ConnectService
+	paymentLinks  *stripe.PaymentLinkService
+}
+
+// NewStripeGateway creates a new Stripe payment gateway instance. Stripe's
+// client is shared by the whole process, so the key and egress settings
+// apply to every Stripe call.
+func NewStripeGateway(config map[string]interface{}) (*StripeGateway, error) {
+	if err := validateStripeConfig(config); err != nil {
+		return nil, err
+	}
+
+	egressConfig, err := egress.LoadConfig("stripe")
+	if err != nil {
+		return nil, &InvalidConfigError{Message: err.Error()}
+	}
+	transport, err := egress.NewTransport(egressConfig)
+	if err != nil {
+		return nil, &InvalidConfigError{Message: err.Error()}
+	}
+
+	stripe.Configure(config["api_key"].(string), &http.Client{Transport: transport, Timeout: egressConfig.RequestTimeout})
+
+	return NewStripeGatewayWithServices(
+		stripe.NewCustomerService(),
+		stripe.NewChargeService(),
+		stripe.NewRefundService(),
+		stripe.NewSubscriptionService(),
+		stripe.NewDisputeService(),
+		stripe.NewInvoiceService(),
+		stripe.NewConnectService(),
+		stripe.NewPaymentLinkService(),
+	), nil
+}
+
+// NewStripeGatewayWithServices creates a Stripe gateway over services that
+// are already configured, such as those the API uses
+func NewStripeGatewayWithServices(customers *stripe.CustomerService, charges *stripe.ChargeService, refunds *stripe.RefundService, subscriptions *stripe.SubscriptionService, disputes *stripe.DisputeService, invoices *stripe.InvoiceService, connect *stripe.ConnectService, paymentLinks *stripe.PaymentLinkService) *StripeGateway {
+	return &StripeGateway{
+		customers:     customers,
+		charges:       charges,
+		refunds:       refunds,
+		subscriptions: subscriptions,
+		disputes:      disputes,
+		invoices:      invoices,
+		connect:       connect,
+		paymentLinks:  paymentLinks,
+	}
+}
+
+// GetProvider returns the provider name
+func (g *StripeGateway) GetProvider() string {
+	return "stripe"
+}
+
+// GetCapabilities returns the capabilities supported by Stripe
+func (g *StripeGateway) GetCapabilities() GatewayCapabilities {
+	return GatewayCapabilities{
+		SupportsCustomers:     true,
+		SupportsCharges:       true,
+		SupportsRefunds:       true,
+		SupportsSubscriptions: true,
+		SupportsDisputes:      true,
+		SupportsConnect:       true,
+		SupportsTax:           true,
+		SupportsInvoices:      true,
+		SupportsPaymentLinks:  true,
+		MaxChargeAmount:       99999999, // $999,999.99 in cents
+		MinChargeAmount:       50,       // $0.50 in cents
+		SupportedCurrencies:   []string{"usd", "eur", "gbp", "cad", "aud", "jpy"},
+		SupportedCountries:    []string{"US", "CA", "GB", "DE", "FR", "AU", "JP"},
+		BNPLMethods: []BNPLMethod{
+			{
+				Type:          stripe.PaymentMethodTypeKlarna,
+				CaptureWindow: stripe.CaptureWindow(stripe.PaymentMethodTypeKlarna),
+				Currencies:    []string{"usd", "eur", "gbp", "aud", "cad", "nzd", "chf", "dkk", "nok", "sek", "czk", "pln"},
+			},
+			{
+				Type:          stripe.PaymentMethodTypeAfterpay,
+				CaptureWindow: stripe.CaptureWindow(stripe.PaymentMethodTypeAfterpay),
+				Currencies:    []string{"usd", "cad", "gbp", "aud", "nzd"},
+			},
+		},
+		SupportsMultiCapture: true,
+		AuthorizationWindow:  7 * 24 * time.Hour, // online card payments
+	}
+}
+
+// Customer management implementation
+
+func (g *StripeGateway) CreateCustomer(ctx context.Context, req CreateCustomerRequest) (*Customer, error) {
+	customer, err := g.customers.CreateCustomer(ctx, &stripe.CustomerRequest{
+		Email:    req.Email,
+		Name:     req.Name,
+		Phone:    req.Phone,
+		Address:  toStripeAddress(req.Address),
+		Metadata: stripeMetadata(req.Metadata),
+	})
+	if err != nil {
+		return nil, g.paymentError("customer_creation_failed", "failed to create customer", err)
+	}
+
+	return convertStripeCustomer(customer), nil
+}
+
+func (g *StripeGateway) GetCustomer(ctx context.Context, customerID string) (*Customer, error) {
+	customer, err := g.customers.GetCustomer(ctx, customerID)
+	if err != nil {
+		return nil, g.paymentError("customer_retrieval_failed", "failed to retrieve customer", err)
+	}
+
+	return convertStripeCustomer(customer), nil
+}
+
+// UpdateCustomer changes only the fields set on the request
+func (g *StripeGateway) UpdateCustomer(ctx context.Context, customerID string, req UpdateCustomerRequest) (*Customer, error) {
+	current, err := g.customers.GetCustomer(ctx, customerID)
+	if err != nil {
+		return nil, g.paymentError("customer_update_failed", "failed to retrieve customer", err)
+	}
+
+	request := &stripe.CustomerRequest{
+		Email:       current.Email,
+		Name:        current.Name,
+		Phone:       current.Phone,
+		Description: current.Description,
+		Address:     current.Address,
+		Metadata:    current.Metadata,
+	}
+	if req.Email != "" {
+		request.Email = req.Email
+	}
+	if req.Name != "" {
+		request.Name = req.Name
+	}
+	if req.Phone != "" {
+		request.Phone = req.Phone
+	}
+	if req.Address != nil {
+		request.Address = toStripeAddress(req.Address)
+	}
+	if req.Metadata != nil {
+		request.Metadata = stripeMetadata(req.Metadata)
+	}
+
+	customer, err := g.customers.UpdateCustomer(ctx, customerID, request)
+	if err != nil {
+		return nil, g.paymentError("customer_update_failed", "failed to update customer", err)
+	}
+
+	return convertStripeCustomer(customer), nil
+}
+
+func (g *StripeGateway) DeleteCustomer(ctx context.Context, customerID string) error {
+	if err := g.customers.DeleteCustomer(ctx, customerID); err != nil {
+		return g.paymentError("customer_deletion_failed", "failed to delete customer", err)
+	}
+	return nil
+}
+
+func (g *StripeGateway) ListCustomers(ctx context.Context, req ListCustomersRequest) (*CustomerList, error) {
+	limit := stripeListLimit(req.Limit)
+
+	// One extra customer tells whether there are more
+	customers, err := g.customers.ListCustomers(ctx, req.Email, stripe.Page{Limit: int64(limit + 1), StartingAfter: req.Cursor})
+	if err != nil {
+		return nil, g.paymentError("customer_list_failed", "failed to list customers", err)
+	}
+
+	list := &CustomerList{HasMore: len(customers) > limit}
+	for _, customer := range customers[:min(len(customers), limit)] {
+		list.Customers = append(list.Customers, convertStripeCustomer(customer))
+	}
+	list.Total = len(list.Customers)
+	if list.HasMore {
+		list.NextCursor = list.Customers[len(list.Customers)-1].ID
+	}
+
+	return list, nil
+}
+
+// AddPaymentMethod creates a payment method from the Stripe.js card token in
+// metadata token and attaches it to the customer
+func (g *StripeGateway) AddPaymentMethod(ctx context.Context, customerID string, req AddPaymentMethodRequest) (*PaymentMethod, error) {
+	if req.Type != "" && req.Type != "card" {
+		return nil, g.notSupported("stripe payment methods other than cards are added with SetupIntents")
+	}
+	token, _ := req.Metadata[stripePaymentMethodTokenKey].(string)
+	if token == "" {
+		return nil, &PaymentError{Code: "invalid_request", Message: "metadata token is required to add a card with stripe", Provider: "stripe"}
+	}
+
+	metadata := stripeMetadata(req.Metadata)
+	delete(metadata, stripePaymentMethodTokenKey)
+
+	paymentMethod, err := g.customers.AddPaymentMethod(ctx, &stripe.PaymentMethodRequest{
+		Type:     "card",
+		Card:     &stripe.CardRequest{Token: token},
+		Customer: customerID,
+		Metadata: metadata,
+	})
+	if err != nil {
+		return nil, g.paymentError("payment_method_creation_failed", "failed to create payment method", err)
+	}
+
+	return convertStripePaymentMethod(paymentMethod), nil
+}
+
+// RemovePaymentMethod detaches a payment method from the customer it belongs to
+func (g *StripeGateway) RemovePaymentMethod(ctx context.Context, customerID string, paymentMethodID string) error {
+	paymentMethod, err := g.customers.GetPaymentMethod(ctx, paymentMethodID)
+	if err != nil {
+		return g.paymentError("payment_method_removal_failed", "failed to retrieve payment method", err)
+	}
+	if paymentMethod.Customer != customerID {
+		return &PaymentError{Code: "not_found", Message: "payment method does not belong to the customer", Provider: "stripe"}
+	}
+
+	if err := g.customers.DetachPaymentMethod(ctx, paymentMethodID); err != nil {
+		return g.paymentError("payment_method_removal_failed", "failed to remove payment method", err)
+	}
+	return nil
+}
+
+func (g *StripeGateway) ListPaymentMethods(ctx context.Context, customerID string, req ListPaymentMethodsRequest) (*PaymentMethodList, error) {
+	limit := stripeListLimit(req.Limit)
+
+	paymentMethods, err := g.customers.ListPaymentMethods(ctx, customerID, stripe.Page{Limit: int64(limit + 1), StartingAfter: req.Cursor})
+	if err != nil {
+		return nil, g.paymentError("payment_method_list_failed", "failed to list payment methods", err)
+	}
+
+	list := &PaymentMethodList{HasMore: len(paymentMethods) > limit}
+	for _, paymentMethod := range paymentMethods[:min(len(paymentMethods), limit)] {
+		list.PaymentMethods = append(list.PaymentMethods, convertStripePaymentMethod(paymentMethod))
+	}
+	if list.HasMore {
+		list.NextCursor = list.PaymentMethods[len(list.PaymentMethods)-1].ID
+	}
+
+	return list, nil
+}
+
+// Payment processing implementation
+
+// CreateCharge charges through the stripe package's charge service, so charge
+// guards and mirrors apply to gateway charges too. Uncaptured charges are
+// only authorized.
+func (g *StripeGateway) CreateCharge(ctx context.Context, req CreateChargeRequest) (*Charge, error) {
+	charge, err := g.charges.CreateCharge(ctx, stripeChargeRequest(req))
+	if err != nil {
+		return nil, g.paymentError("charge_creation_failed", "failed to create charge", err)
+	}
+
+	return convertStripeCharge(charge), nil
+}
+
+func (g *StripeGateway) GetCharge(ctx context.Context, chargeID string) (*Charge, error) {
+	charge, err := g.charges.GetCharge(ctx, chargeID)
+	if err != nil {
+		return nil, g.paymentError("charge_retrieval_failed", "failed to retrieve charge", err)
+	}
+
+	return convertStripeCharge(charge), nil
+}
+
+func (g *StripeGateway) UpdateCharge(ctx context.Context, chargeID string, req UpdateChargeRequest) (*Charge, error) {
+	charge, err := g.charges.UpdateCharge(ctx, chargeID, req.Description, stripeMetadata(req.Metadata))
+	if err != nil {
+		return nil, g.paymentError("charge_update_failed", "failed to update charge", err)
+	}
+
+	return convertStripeCharge(charge), nil
+}
+
+// CaptureCharge captures an authorized charge. Charges created with
+// MultiCapture on a card that allows it can be captured in several parts.
+func (g *StripeGateway) CaptureCharge(ctx context.Context, chargeID string, req CaptureChargeRequest) (*Charge, error) {
+	charge, err := g.charges.CaptureChargePart(ctx, chargeID, req.Amount, !req.KeepOpen)
+	if errors.Is(err, stripe.ErrMultiCaptureUnavailable) {
+		return nil, g.notSupported(err.Error())
+	}
+	if err != nil {
+		return nil, g.paymentError("charge_capture_failed", "failed to capture charge", err)
+	}
+
+	return convertStripeCharge(charge), nil
+}
+
+// VoidCharge releases an uncaptured charge's authorization
+func (g *StripeGateway) VoidCharge(ctx context.Context, chargeID string) (*Charge, error) {
+	charge, err := g.charges.VoidCharge(ctx, chargeID)
+	if err != nil {
+		return nil, g.paymentError("charge_void_failed", "failed to void charge", err)
+	}
+
+	return convertStripeCharge(charge), nil
+}
+
+// ListCharges lists charges, newest first. Stripe cannot filter charges by
+// status, so a status filter is applied to every charge after the cursor.
+func (g *StripeGateway) ListCharges(ctx context.Context, req ListChargesRequest) (*ChargeList, error) {
+	limit := stripeListLimit(req.Limit)
+
+	page := stripe.Page{Limit: int64(limit + 1), StartingAfter: req.Cursor}
+	if req.Status != "" {
+		page.Limit = 0
+	}
+
+	charges, err := g.charges.ListCharges(ctx, req.CustomerID, page)
+	if err != nil {
+		return nil, g.paymentError("charge_list_failed", "failed to list charges", err)
+	}
+
+	list := &ChargeList{}
+	for _, charge := range charges {
+		converted := convertStripeCharge(charge)
+		if req.Status != "" && converted.Status != req.Status {
+			continue
+		}
+		if len(list.Charges) == limit {
+			list.HasMore = true
+			break
+		}
+		list.Charges = append(list.Charges, converted)
+	}
+	list.Total = len(list.Charges)
+	if list.HasMore {
+		list.NextCursor = list.Charges[len(list.Charges)-1].ID
+	}
+
+	return list, nil
+}
+
+// Refund processing implementation
+
+func (g *StripeGateway) CreateRefund(ctx context.Context, req CreateRefundRequest) (*Refund, error) {
+	refund, err := g.refunds.CreateRefund(ctx, &stripe.RefundRequest{
+		ChargeID: req.ChargeID,
+		Amount:   req.Amount,
+		Reason:   req.Reason,
+	})
+	if err != nil {
+		return nil, g.paymentError("refund_creation_failed", "failed to create refund", err)
+	}
+
+	return convertStripeRefund(refund), nil
+}
+
+func (g *StripeGateway) GetRefund(ctx context.Context, refundID string) (*Refund, error) {
+	refund, err := g.refunds.GetRefund(ctx, refundID)
+	if err != nil {
+		return nil, g.paymentError("refund_retrieval_failed", "failed to retrieve refund", err)
+	}
+
+	return convertStripeRefund(refund), nil
+}
+
+func (g *StripeGateway) UpdateRefund(ctx context.Context, refundID string, req UpdateRefundRequest) (*Refund, error) {
+	refund, err := g.refunds.UpdateRefund(ctx, refundID, stripeMetadata(req.Metadata))
+	if err != nil {
+		return nil, g.paymentError("refund_update_failed", "failed to update refund", err)
+	}
+
+	return convertStripeRefund(refund), nil
+}
+
+// ListRefunds lists the refunds of a charge
+func (g *StripeGateway) ListRefunds(ctx context.Context, req ListRefundsRequest) (*RefundList, error) {
+	if req.ChargeID == "" {
+		return nil, &PaymentError{Code: "invalid_request", Message: "charge_id is required to list stripe refunds", Provider: "stripe"}
+	}
+	limit := stripeListLimit(req.Limit)
+
+	refunds, err := g.refunds.ListRefunds(ctx, req.ChargeID, stripe.Page{Limit: int64(limit + 1), StartingAfter: req.Cursor})
+	if err != nil {
+		return nil, g.paymentError("refund_list_failed", "failed to list refunds", err)
+	}
+
+	list := &RefundList{HasMore: len(refunds) > limit}
+	for _, refund := range refunds[:min(len(refunds), limit)] {
+		list.Refunds = append(list.Refunds, convertStripeRefund(refund))
+	}
+	list.Total = len(list.Refunds)
+	if list.HasMore {
+		list.NextCursor = list.Refunds[len(list.Refunds)-1].ID
+	}
+
+	return list, nil
+}
+
+// Subscription management implementation
+
+func (g *StripeGateway) CreateSubscription(ctx context.Context, req CreateSubscriptionRequest) (*Subscription, error) {
+	subscription, err := g.subscriptions.CreateSubscription(ctx, &stripe.SubscriptionRequest{
+		CustomerID: req.CustomerID,
+		PriceID:    req.PlanID,
+		Metadata:   stripeMetadata(req.Metadata),
+	})
+	if err != nil {
+		return nil, g.paymentError("subscription_creation_failed", "failed to create subscription", err)
+	}
+
+	return convertStripeSubscription(subscription), nil
+}
+
+func (g *StripeGateway) GetSubscription(ctx context.Context, subscriptionID string) (*Subscription, error) {
+	subscription, err := g.subscriptions.GetSubscription(ctx, subscriptionID)
+	if err != nil {
+		return nil, g.paymentError("subscription_retrieval_failed", "failed to retrieve subscription", err)
+	}
+
+	return convertStripeSubscription(subscription), nil
+}
+
+func (g *StripeGateway) UpdateSubscription(ctx context.Context, subscriptionID string, req UpdateSubscriptionRequest) (*Subscription, error) {
+	subscription, err := g.subscriptions.UpdateSubscription(ctx, subscriptionID, &stripe.SubscriptionChange{PriceID: req.PlanID}, stripeMetadata(req.Metadata))
+	if err != nil {
+		return nil, g.paymentError("subscription_update_failed", "failed to update subscription", err)
+	}
+
+	return convertStripeSubscription(subscription), nil
+}
+
+func (g *StripeGateway) CancelSubscription(ctx context.Context, subscriptionID string, req CancelSubscriptionRequest) (*Subscription, error) {
+	subscription, err := g.subscriptions.CancelSubscription(ctx, subscriptionID, req.AtPeriodEnd)
+	if err != nil {
+		return nil, g.paymentError("subscription_cancellation_failed", "failed to cancel subscription", err)
+	}
+
+	return convertStripeSubscription(subscription), nil
+}
+
+func (g *StripeGateway) ListSubscriptions(ctx context.Context, req ListSubscriptionsRequest) (*SubscriptionList, error) {
+	limit := stripeListLimit(req.Limit)
+
+	subscriptions, err := g.subscriptions.ListSubscriptions(ctx, req.CustomerID, req.Status, stripe.Page{Limit: int64(limit + 1), StartingAfter: req.Cursor})
+	if err != nil {
+		return nil, g.paymentError("subscription_list_failed", "failed to list subscriptions", err)
+	}
+
+	list := &SubscriptionList{HasMore: len(subscriptions) > limit}
+	for _, subscription := range subscriptions[:min(len(subscriptions), limit)] {
+		list.Subscriptions = append(list.Subscriptions, convertStripeSubscription(subscription))
+	}
+	list.Total = len(list.Subscriptions)
+	if list.HasMore {
+		list.NextCursor = list.Subscriptions[len(list.Subscriptions)-1].ID
+	}
+
+	return list, nil
+}
+
+// Dispute management implementation
+
+func (g *StripeGateway) GetDispute(ctx context.Context, disputeID string) (*Dispute, error) {
+	dispute, err := g.disputes.GetDispute(ctx, disputeID)
+	if err != nil {
+		return nil, g.paymentError("dispute_retrieval_failed", "failed to retrieve dispute", err)
+	}
+
+	return convertStripeDispute(dispute), nil
+}
+
+// ListDisputes lists disputes, newest first. Stripe cannot filter disputes by
+// status, so a status filter is applied to every dispute after the cursor.
+func (g *StripeGateway) ListDisputes(ctx context.Context, req ListDisputesRequest) (*DisputeList, error) {
+	limit := stripeListLimit(req.Limit)
+
+	page := stripe.Page{Limit: int64(limit + 1), StartingAfter: req.Cursor}
+	if req.Status != "" {
+		page.Limit = 0
+	}
+
+	disputes, err := g.disputes.ListDisputes(ctx, req.ChargeID, page)
+	if err != nil {
+		return nil, g.paymentError("dispute_list_failed", "failed to list disputes", err)
+	}
+
+	list := &DisputeList{}
+	for _, dispute := range disputes {
+		if req.Status != "" && dispute.Status != req.Status {
+			continue
+		}
+		if len(list.Disputes) == limit {
+			list.HasMore = true
+			break
+		}
+		list.Disputes = append(list.Disputes, convertStripeDispute(dispute))
+	}
+	list.Total = len(list.Disputes)
+	if list.HasMore {
+		list.NextCursor = list.Disputes[len(list.Disputes)-1].ID
+	}
+
+	return list, nil
+}
+
+func (g *StripeGateway) AcceptDispute(ctx context.Context, disputeID string) (*Dispute, error) {
+	dispute, err := g.disputes.CloseDispute(ctx, disputeID)
+	if err != nil {
+		return nil, g.paymentError("dispute_acceptance_failed", "failed to accept dispute", err)
+	}
+
+	return convertStripeDispute(dispute), nil
+}
+
+// SubmitDisputeEvidence stages evidence keyed by Stripe's evidence field
+// names, e.g. uncategorized_text
+func (g *StripeGateway) SubmitDisputeEvidence(ctx context.Context, disputeID string, req SubmitDisputeEvidenceRequest) (*Dispute, error) {
+	evidence := make(map[string]string, len(req.Evidence))
+	for _, item := range req.Evidence {
+		evidence[item.Type] = item.Text
+	}
+
+	dispute, err := g.disputes.UpdateDisputeEvidence(ctx, disputeID, evidence, req.Submit)
+	if err != nil {
+		return nil, g.paymentError("dispute_evidence_failed", "failed to submit dispute evidence", err)
+	}
+
+	return convertStripeDispute(dispute), nil
+}
+
+// Invoice management implementation
+
+func (g *StripeGateway) CreateInvoice(ctx context.Context, req CreateInvoiceRequest) (*Invoice, error) {
+	request := &stripe.CreateInvoiceRequest{
+		CustomerID:       req.CustomerID,
+		Currency:         req.Currency,
+		Description:      req.Description,
+		CollectionMethod: req.CollectionMethod,
+		DaysUntilDue:     int64(req.DaysUntilDue),
+		Metadata:         stripeMetadata(req.Metadata),
+	}
+	for _, item := range req.Items {
+		request.Items = append(request.Items, stripe.InvoiceItemRequest{
+			Amount:      item.Amount,
+			Description: item.Description,
+		})
+	}
+
+	invoice, err := g.invoices.CreateInvoice(ctx, request)
+	if err != nil {
+		return nil, g.paymentError("invoice_creation_failed", "failed to create invoice", err)
+	}
+
+	return convertStripeInvoice(invoice), nil
+}
+
+func (g *StripeGateway) GetInvoice(ctx context.Context, invoiceID string) (*Invoice, error) {
+	invoice, err := g.invoices.GetInvoice(ctx, invoiceID)
+	if err != nil {
+		return nil, g.paymentError("invoice_retrieval_failed", "failed to retrieve invoice", err)
+	}
+
+	return convertStripeInvoice(invoice), nil
+}
+
+func (g *StripeGateway) FinalizeInvoice(ctx context.Context, invoiceID string) (*Invoice, error) {
+	invoice, err := g.invoices.FinalizeInvoice(ctx, invoiceID)
+	if err != nil {
+		return nil, g.paymentError("invoice_finalization_failed", "failed to finalize invoice", err)
+	}
+
+	return convertStripeInvoice(invoice), nil
+}
+
+func (g *StripeGateway) PayInvoice(ctx context.Context, invoiceID string, req PayInvoiceRequest) (*Invoice, error) {
+	invoice, err := g.invoices.PayInvoice(ctx, invoiceID, req.PaymentMethodID)
+	if err != nil {
+		return nil, g.paymentError("invoice_payment_failed", "failed to pay invoice", err)
+	}
+
+	return convertStripeInvoice(invoice), nil
+}
+
+func (g *StripeGateway) VoidInvoice(ctx context.Context, invoiceID string) (*Invoice, error) {
+	invoice, err := g.invoices.VoidInvoice(ctx, invoiceID)
+	if err != nil {
+		return nil, g.paymentError("invoice_void_failed", "failed to void invoice", err)
+	}
+
+	return convertStripeInvoice(invoice), nil
+}
+
+func (g *StripeGateway) ListInvoices(ctx context.Context, req ListInvoicesRequest) (*InvoiceList, error) {
+	limit := stripeListLimit(req.Limit)
+
+	invoices, err := g.invoices.ListInvoices(ctx, req.CustomerID, req.Status, stripe.Page{Limit: int64(limit + 1), StartingAfter: req.Cursor})
+	if err != nil {
+		return nil, g.paymentError("invoice_list_failed", "failed to list invoices", err)
+	}
+
+	list := &InvoiceList{HasMore: len(invoices) > limit}
+	for _, invoice := range invoices[:min(len(invoices), limit)] {
+		list.Invoices = append(list.Invoices, convertStripeInvoice(invoice))
+	}
+	list.Total = len(list.Invoices)
+	if list.HasMore {
+		list.NextCursor = list.Invoices[len(list.Invoices)-1].ID
+	}
+
+	return list, nil
+}
+
+// GetInvoicePDFURL returns the invoice's PDF link. Stripe only renders PDFs
+// of finalized invoices.
+func (g *StripeGateway) GetInvoicePDFURL(ctx context.Context, invoiceID string) (string, error) {
+	invoice, err := g.invoices.GetInvoice(ctx, invoiceID)
+	if err != nil {
+		return "", g.paymentError("invoice_retrieval_failed", "failed to retrieve invoice", err)
+	}
+	if invoice.InvoicePDF == "" {
+		return "", &PaymentError{Code: "invoice_pdf_unavailable", Message: "invoice has no PDF until it is finalized", Provider: "stripe"}
+	}
+
+	return invoice.InvoicePDF, nil
+}
+
+// Connect implementation
+
+func (g *StripeGateway) CreateConnectedAccount(ctx context.Context, req CreateConnectedAccountRequest) (*ConnectedAccount, error) {
+	account, err := g.connect.CreateAccount(ctx, &stripe.CreateAccountRequest{
+		Type:     req.Type,
+		Country:  req.Country,
+		Email:    req.Email,
+		Metadata: stripeMetadata(req.Metadata),
+	})
+	if err != nil {
+		return nil, g.paymentError("account_creation_failed", "failed to create connected account", err)
+	}
+
+	return convertStripeConnectedAccount(account), nil
+}
+
+func (g *StripeGateway) GetConnectedAccount(ctx context.Context, accountID string) (*ConnectedAccount, error) {
+	account, err := g.connect.GetAccount(ctx, accountID)
+	if err != nil {
+		return nil, g.paymentError("account_retrieval_failed", "failed to retrieve connected account", err)
+	}
+
+	return convertStripeConnectedAccount(account), nil
+}
+
+func (g *StripeGateway) CreateOnboardingLink(ctx context.Context, accountID string, req CreateOnboardingLinkRequest) (*OnboardingLink, error) {
+	link, err := g.connect.CreateAccountLink(ctx, accountID, &stripe.AccountLinkRequest{
+		RefreshURL: req.RefreshURL,
+		ReturnURL:  req.ReturnURL,
+	})
+	if err != nil {
+		return nil, g.paymentError("onboarding_link_failed", "failed to create onboarding link", err)
+	}
+
+	return &OnboardingLink{
+		AccountID: link.AccountID,
+		URL:       link.URL,
+		ExpiresAt: time.Unix(link.ExpiresAt, 0),
+	}, nil
+}
+
+// CreateDestinationCharge charges through the charge service like
+// CreateCharge, settling the charge to the connected account
+func (g *StripeGateway) CreateDestinationCharge(ctx context.Context, accountID string, req CreateDestinationChargeRequest) (*Charge, error) {
+	request := stripeChargeRequest(req.CreateChargeRequest)
+	request.Destination = accountID
+	request.ApplicationFeeAmount = req.ApplicationFeeAmount
+
+	charge, err := g.charges.CreateCharge(ctx, request)
+	if err != nil {
+		return nil, g.paymentError("charge_creation_failed", "failed to create destination charge", err)
+	}
+
+	return convertStripeCharge(charge), nil
+}
+
+func (g *StripeGateway) CreateTransfer(ctx context.Context, req CreateTransferRequest) (*Transfer, error) {
+	transfer, err := g.connect.CreateTransfer(ctx, &stripe.TransferRequest{
+		Amount:            req.Amount,
+		Currency:          req.Currency,
+		Destination:       req.AccountID,
+		SourceTransaction: req.SourceChargeID,
+		TransferGroup:     req.TransferGroup,
+		Metadata:          stripeMetadata(req.Metadata),
+	})
+	if err != nil {
+		return nil, g.paymentError("transfer_creation_failed", "failed to create transfer", err)
+	}
+
+	return convertStripeTransfer(transfer), nil
+}
+
+func (g *StripeGateway) ListTransfers(ctx context.Context, req ListTransfersRequest) (*TransferList, error) {
+	limit := stripeListLimit(req.Limit)
+
+	transfers, err := g.connect.ListTransfers(ctx, req.AccountID, stripe.Page{Limit: int64(limit + 1), StartingAfter: req.Cursor})
+	if err != nil {
+		return nil, g.paymentError("transfer_list_failed", "failed to list transfers", err)
+	}
+
+	list := &TransferList{HasMore: len(transfers) > limit}
+	for _, transfer := range transfers[:min(len(transfers), limit)] {
+		list.Transfers = append(list.Transfers, convertStripeTransfer(transfer))
+	}
+	list.Total = len(list.Transfers)
+	if list.HasMore {
+		list.NextCursor = list.Transfers[len(list.Transfers)-1].ID
+	}
+
+	return list, nil
+}
+
+// Payment link implementation
+
+func (g *StripeGateway) CreatePaymentLink(ctx context.Context, req CreatePaymentLinkRequest) (*PaymentLink, error) {
+	link, err := g.paymentLinks.CreatePaymentLink(ctx, &stripe.PaymentLinkRequest{
+		Amount:      req.Amount,
+		Currency:    req.Currency,
+		ProductName: req.ProductName,
+		MaxUses:     req.MaxUses,
+		Metadata:    stripeMetadata(req.Metadata),
+	})
+	if err != nil {
+		return nil, g.paymentError("payment_link_creation_failed", "failed to create payment link", err)
+	}
+
+	return convertStripePaymentLink(link), nil
+}
+
+func (g *StripeGateway) GetPaymentLink(ctx context.Context, linkID string) (*PaymentLink, error) {
+	link, err := g.paymentLinks.GetPaymentLink(ctx, linkID)
+	if err != nil {
+		return nil, g.paymentError("payment_link_retrieval_failed", "failed to retrieve payment link", err)
+	}
+
+	return convertStripePaymentLink(link), nil
+}
+
+func (g *StripeGateway) ListPaymentLinks(ctx context.Context, req ListPaymentLinksRequest) (*PaymentLinkList, error) {
+	limit := stripeListLimit(req.Limit)
+
+	links, err := g.paymentLinks.ListPaymentLinks(ctx, req.ActiveOnly, stripe.Page{Limit: int64(limit + 1), StartingAfter: req.Cursor})
+	if err != nil {
+		return nil, g.paymentError("payment_link_list_failed", "failed to list payment links", err)
+	}
+
+	list := &PaymentLinkList{HasMore: len(links) > limit}
+	for _, link := range links[:min(len(links), limit)] {
+		list.PaymentLinks = append(list.PaymentLinks, convertStripePaymentLink(link))
+	}
+	list.Total = len(list.PaymentLinks)
+	if list.HasMore {
+		list.NextCursor = list.PaymentLinks[len(list.PaymentLinks)-1].ID
+	}
+
+	return list, nil
+}
+
+func (g *StripeGateway) DeactivatePaymentLink(ctx context.Context, linkID string) (*PaymentLink, error) {
+	link, err := g.paymentLinks.DeactivatePaymentLink(ctx, linkID)
+	if err != nil {
+		return nil, g.paymentError("payment_link_deactivation_failed", "failed to deactivate payment link", err)
+	}
+
+	return convertStripePaymentLink(link), nil
+}
+
+// Stripe helpers
+
+func (g *StripeGateway) notSupported(message string) error {
+	return &PaymentError{Code: "not_supported", Message: message, Provider: "stripe"}
+}
+
+func (g *StripeGateway) paymentError(code, message string, err error) error {
+	return &PaymentError{
+		Code:     code,
+		Message:  fmt.Sprintf("%s: %v", message, err),
+		Provider: "stripe",
+	}
+}
+
+// stripeChargeRequest converts a gateway charge request for the charge service
+func stripeChargeRequest(req CreateChargeRequest) *stripe.ChargeRequest {
+	request := &stripe.ChargeRequest{
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+		CustomerID:    req.CustomerID,
+		Description:   req.Description,
+		PaymentMethod: req.PaymentMethodID,
+		Metadata:      stripeMetadata(req.Metadata),
+
+		StatementDescriptor:       req.StatementDescriptor,
+		StatementDescriptorSuffix: req.StatementDescriptorSuffix,
+		ReceiptEmail:              req.ReceiptEmail,
+	}
+	if !req.Capture {
+		request.CaptureMethod = "manual"
+		request.MultiCapture = req.MultiCapture
+	}
+	return request
+}
+
+// stripeListLimit returns the page size for a list request
+func stripeListLimit(limit int) int {
+	if limit <= 0 {
+		return stripeDefaultListLimit
+	}
+	return limit
+}
+
+// stripeMetadata converts gateway metadata to Stripe's string metadata
+func stripeMetadata(metadata map[string]interface{}) map[string]string {
+	if metadata == nil {
+		return nil
+	}
+
+	converted := make(map[string]string, len(metadata))
+	for key, value := range metadata {
+		converted[key] = fmt.Sprint(value)
+	}
+	return converted
+}
+
+// gatewayMetadata converts Stripe's string metadata to gateway metadata
+func gatewayMetadata(metadata map[string]string) map[string]interface{} {
+	if len(metadata) == 0 {
+		return nil
+	}
+
+	converted := make(map[string]interface{}, len(metadata))
+	for key, value := range metadata {
+		converted[key] = value
+	}
+	return converted
+}
+
+func toStripeAddress(address *Address) *stripe.Address {
+	if address == nil {
+		return nil
+	}
+
+	return &stripe.Address{
+		Line1:      address.Line1,
+		Line2:      address.Line2,
+		City:       address.City,
+		State:      address.State,
+		PostalCode: address.PostalCode,
+		Country:    address.Country,
+	}
+}
+
+// Stripe resource converters
+
+func convertStripeCustomer(sc *stripe.Customer) *Customer {
+	customer := &Customer{
+		ID:         sc.ID,
+		Email:      sc.Email,
+		Name:       sc.Name,
+		Phone:      sc.Phone,
+		Metadata:   gatewayMetadata(sc.Metadata),
+		CreatedAt:  time.Unix(sc.Created, 0),
+		UpdatedAt:  time.Unix(sc.Updated, 0),
+		ProviderID: sc.ID,
+		Provider:   "stripe",
+	}
+
+	if sc.Address != nil {
+		customer.Address = &Address{
+			Line1:      sc.Address.Line1,
+			Line2:      sc.Address.Line2,
+			City:       sc.Address.City,
+			State:      sc.Address.State,
+			PostalCode: sc.Address.PostalCode,
+			Country:    sc.Address.Country,
+		}
+	}
+
+	return customer
+}
+
+func convertStripePaymentMethod(spm *stripe.PaymentMethod) *PaymentMethod {
+	paymentMethod := &PaymentMethod{
+		ID:         spm.ID,
+		CustomerID: spm.Customer,
+		Type:       spm.Type,
+		Metadata:   gatewayMetadata(spm.Metadata),
+		CreatedAt:  time.Unix(spm.Created, 0),
+		ProviderID: spm.ID,
+		Provider:   "stripe",
+	}
+
+	if spm.Card != nil {
+		paymentMethod.Card = &Card{
+			Brand:       spm.Card.Brand,
+			Last4:       spm.Card.Last4,
+			ExpMonth:    spm.Card.ExpMonth,
+			ExpYear:     spm.Card.ExpYear,
+			Fingerprint: spm.Card.Fingerprint,
+		}
+	}
+
+	if spm.BankAccount != nil {
+		paymentMethod.BankAccount = &BankAccount{
+			BankName:      spm.BankAccount.BankName,
+			Last4:         spm.BankAccount.Last4,
+			RoutingNumber: spm.BankAccount.RoutingNumber,
+			AccountType:   spm.BankAccount.AccountType,
+			Country:       spm.BankAccount.Country,
+		}
+	}
+
+	return paymentMethod
+}
+
+func convertStripeCharge(sc *stripe.Charge) *Charge {
+	charge := &Charge{
+		ID:              sc.ID,
+		Amount:          sc.Amount,
+		Currency:        sc.Currency,
+		CustomerID:      sc.CustomerID,
+		PaymentMethodID: sc.PaymentMethodID,
+		Status:          convertStripeChargeStatus(sc),
+		Description:     sc.Description,
+		Metadata:        gatewayMetadata(sc.Metadata),
+		CreatedAt:       time.Unix(sc.Created, 0),
+		UpdatedAt:       time.Unix(sc.Created, 0), // Stripe doesn't provide updated_at
+		ProviderID:      sc.ID,
+		Provider:        "stripe",
+
+		StatementDescriptor: sc.StatementDescriptor,
+		ReceiptEmail:        sc.ReceiptEmail,
+		ReceiptURL:          sc.ReceiptURL,
+	}
+	if sc.CaptureBefore > 0 && !sc.Captured {
+		captureBefore := time.Unix(sc.CaptureBefore, 0)
+		charge.CaptureBefore = &captureBefore
+	}
+	return charge
+}
+
+// convertStripeChargeStatus reports authorized but uncaptured charges as
+// requires_capture, as the other gateways do
+func convertStripeChargeStatus(sc *stripe.Charge) string {
+	if sc.Status == "succeeded" && !sc.Captured {
+		return "requires_capture"
+	}
+	return sc.Status
+}
+
+func convertStripeRefund(sr *stripe.Refund) *Refund {
+	return &Refund{
+		ID:         sr.ID,
+		ChargeID:   sr.ChargeID,
+		Amount:     sr.Amount,
+		Currency:   sr.Currency,
+		Reason:     sr.Reason,
+		Status:     sr.Status,
+		Metadata:   gatewayMetadata(sr.Metadata),
+		CreatedAt:  sr.CreatedAt,
+		UpdatedAt:  sr.UpdatedAt,
+		ProviderID: sr.ID,
+		Provider:   "stripe",
+	}
+}
+
+func convertStripeSubscription(ss *stripe.Subscription) *Subscription {
+	subscription := &Subscription{
+		ID:         ss.ID,
+		CustomerID: ss.CustomerID,
+		PlanID:     ss.PriceID,
+		Status:     ss.Status,
+		Metadata:   gatewayMetadata(ss.Metadata),
+		CreatedAt:  time.Unix(ss.Created, 0),
+		UpdatedAt:  time.Unix(ss.Created, 0), // Stripe doesn't provide updated_at
+		ProviderID: ss.ID,
+		Provider:   "stripe",
+	}
+
+	if ss.CurrentPeriodStart > 0 {
+		subscription.CurrentPeriodStart = time.Unix(ss.CurrentPeriodStart, 0)
+	}
+	if ss.CurrentPeriodEnd > 0 {
+		subscription.CurrentPeriodEnd = time.Unix(ss.CurrentPeriodEnd, 0)
+	}
+
+	return subscription
+}
+
+func convertStripeInvoice(si *stripe.Invoice) *Invoice {
+	return &Invoice{
+		ID:               si.ID,
+		CustomerID:       si.CustomerID,
+		SubscriptionID:   si.SubscriptionID,
+		Number:           si.Number,
+		Status:           si.Status,
+		CollectionMethod: si.CollectionMethod,
+		Description:      si.Description,
+		AmountDue:        si.AmountDue,
+		AmountPaid:       si.AmountPaid,
+		AmountRemaining:  si.AmountRemaining,
+		Currency:         si.Currency,
+		DueDate:          si.DueDate,
+		HostedURL:        si.HostedInvoiceURL,
+		PDFURL:           si.InvoicePDF,
+		Metadata:         gatewayMetadata(si.Metadata),
+		CreatedAt:        time.Unix(si.Created, 0),
+		ProviderID:       si.ID,
+		Provider:         "stripe",
+	}
+}
+
+func convertStripeConnectedAccount(sa *stripe.ConnectedAccount) *ConnectedAccount {
+	return &ConnectedAccount{
+		ID:               sa.ID,
+		Type:             sa.Type,
+		Email:            sa.Email,
+		Country:          sa.Country,
+		ChargesEnabled:   sa.ChargesEnabled,
+		PayoutsEnabled:   sa.PayoutsEnabled,
+		DetailsSubmitted: sa.DetailsSubmitted,
+		Requirements:     sa.CurrentlyDue,
+		Metadata:         gatewayMetadata(sa.Metadata),
+		CreatedAt:        time.Unix(sa.Created, 0),
+		ProviderID:       sa.ID,
+		Provider:         "stripe",
+	}
+}
+
+func convertStripeTransfer(st *stripe.Transfer) *Transfer {
+	return &Transfer{
+		ID:             st.ID,
+		AccountID:      st.Destination,
+		Amount:         st.Amount,
+		Currency:       st.Currency,
+		SourceChargeID: st.SourceTransaction,
+		TransferGroup:  st.TransferGroup,
+		Reversed:       st.Reversed,
+		Metadata:       gatewayMetadata(st.Metadata),
+		CreatedAt:      time.Unix(st.Created, 0),
+		ProviderID:     st.ID,
+		Provider:       "stripe",
+	}
+}
+
+func convertStripePaymentLink(sl *stripe.PaymentLink) *PaymentLink {
+	return &PaymentLink{
+		ID:         sl.ID,
+		URL:        sl.URL,
+		Active:     sl.Active,
+		Currency:   sl.Currency,
+		MaxUses:    sl.MaxUses,
+		Metadata:   gatewayMetadata(sl.Metadata),
+		ProviderID: sl.ID,
+		Provider:   "stripe",
+	}
+}
+
+func convertStripeDispute(sd *stripe.Dispute) *Dispute {
+	dispute := &Dispute{
+		ID:         sd.ID,
+		ChargeID:   sd.ChargeID,
+		Amount:     sd.Amount,
+		Currency:   sd.Currency,
+		Reason:     sd.Reason,
+		Status:     sd.Status,
+		CreatedAt:  time.Unix(sd.Created, 0),
+		UpdatedAt:  time.Unix(sd.Created, 0), // Stripe doesn't provide updated_at
+		ProviderID: sd.ID,
+		Provider:   "stripe",
+	}
+
+	if sd.EvidenceDetails != nil && sd.EvidenceDetails.DueBy != nil {
+		dispute.EvidenceDueBy = *sd.EvidenceDetails.DueBy
+	}
+
+	return dispute
+}
@@ -0,0 +1,74 @@
+package subscriptions
+
+import (
+	"math"
+	"time"
+
+	"apis/payments/services/stripe"
+)
+
+// Prorate calculates what moving a subscription from its current plan to
+// next at the given time would cost, prorated to the second as Stripe does.
+// A quantity of 0 keeps the current quantity. When the billing interval
+// changes the period restarts, so the new plan is charged in full straight
+// away; otherwise the prorations are added to the invoice at period end.
+func Prorate(subscription *stripe.Subscription, current, next *stripe.SubscriptionPlan, quantity int64, at time.Time) (*stripe.ProrationPreview, error) {
+	if current.Currency != next.Currency {
+		return nil, ErrCurrencyMismatch
+	}
+
+	start, end := subscription.CurrentPeriodStart, subscription.CurrentPeriodEnd
+	if at.Unix() < start || at.Unix() >= end {
+		return nil, ErrProrationDate
+	}
+
+	currentQuantity := subscription.Quantity
+	if currentQuantity == 0 {
+		currentQuantity = 1
+	}
+	if quantity == 0 {
+		quantity = currentQuantity
+	}
+
+	currentAmount := licensedAmount(current) * currentQuantity
+	nextAmount := licensedAmount(next) * quantity
+	remaining, period := end-at.Unix(), end-start
+
+	preview := &stripe.ProrationPreview{
+		SubscriptionID: subscription.ID,
+		PriceID:        next.ID,
+		Quantity:       quantity,
+		Currency:       next.Currency,
+		ProrationDate:  at.Unix(),
+		Credit:         -prorated(currentAmount, remaining, period),
+		Source:         stripe.ProrationSourceCalculated,
+	}
+
+	if current.Interval == next.Interval && current.IntervalCount == next.IntervalCount {
+		preview.Charge = prorated(nextAmount, remaining, period)
+		preview.ProratedAmount = preview.Credit + preview.Charge
+		preview.NextInvoiceTotal = nextAmount + preview.ProratedAmount
+		preview.NextInvoiceAt = end
+		return preview, nil
+	}
+
+	preview.Charge = nextAmount
+	preview.ProratedAmount = preview.Credit + preview.Charge
+	preview.NextInvoiceTotal = preview.ProratedAmount
+	preview.NextInvoiceAt = at.Unix()
+	return preview, nil
+}
+
+// licensedAmount is the per-period amount of a plan. Metered plans are
+// billed for usage at period end and are not prorated.
+func licensedAmount(plan *stripe.SubscriptionPlan) int64 {
+	if plan.UsageType == "metered" {
+		return 0
+	}
+	return plan.Amount
+}
+
+// prorated is the share of amount for the remaining seconds of a period
+func prorated(amount, remaining, period int64) int64 {
+	return int64(math.Round(float64(amount) * float64(remaining) / float64(period)))
+}
@@ -0,0 +1,157 @@
+package subscriptions
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"apis/payments/services/events"
+	"apis/payments/services/stripe"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Service creates, changes and cancels subscriptions and publishes an event
+// for every change
+type Service struct {
+	provider Provider
+	emitter  *events.Emitter
+	tracer   trace.Tracer
+}
+
+// NewService creates a new subscription lifecycle service
+func NewService(provider Provider, emitter *events.Emitter) *Service {
+	return &Service{
+		provider: provider,
+		emitter:  emitter,
+		tracer:   otel.Tracer("payments.subscriptions"),
+	}
+}
+
+// Create subscribes a customer to a plan
+func (s *Service) Create(ctx context.Context, request *stripe.SubscriptionRequest) (*stripe.Subscription, error) {
+	ctx, span := s.tracer.Start(ctx, "Create")
+	defer span.End()
+
+	subscription, err := s.provider.CreateSubscription(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	s.emit(ctx, EventCreated, subscription)
+	return subscription, nil
+}
+
+// Update moves a subscription to another plan or quantity or replaces its
+// metadata
+func (s *Service) Update(ctx context.Context, subscriptionID string, request *UpdateRequest) (*stripe.Subscription, error) {
+	ctx, span := s.tracer.Start(ctx, "Update")
+	defer span.End()
+
+	if request.PriceID == "" && request.Quantity == 0 && len(request.Metadata) == 0 {
+		return nil, ErrEmptyUpdate
+	}
+	if request.Quantity < 0 {
+		return nil, ErrInvalidQuantity
+	}
+
+	change := &stripe.SubscriptionChange{
+		PriceID:       request.PriceID,
+		Quantity:      request.Quantity,
+		Prorate:       request.Prorate,
+		ProrationDate: request.ProrationDate,
+	}
+	subscription, err := s.provider.UpdateSubscription(ctx, subscriptionID, change, request.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	s.emit(ctx, EventUpdated, subscription)
+	return subscription, nil
+}
+
+// Preview computes the prorated amount of moving a subscription to another
+// plan or quantity, from the provider where it can preview the change and
+// calculated from the plans otherwise
+func (s *Service) Preview(ctx context.Context, subscriptionID string, request *PreviewRequest) (*stripe.ProrationPreview, error) {
+	ctx, span := s.tracer.Start(ctx, "Preview")
+	defer span.End()
+
+	if request.PriceID == "" && request.Quantity == 0 {
+		return nil, ErrEmptyChange
+	}
+	if request.Quantity < 0 {
+		return nil, ErrInvalidQuantity
+	}
+
+	change := &stripe.SubscriptionChange{
+		PriceID:       request.PriceID,
+		Quantity:      request.Quantity,
+		ProrationDate: request.ProrationDate,
+	}
+	if previewer, ok := s.provider.(Previewer); ok {
+		return previewer.PreviewSubscriptionChange(ctx, subscriptionID, change)
+	}
+
+	subscription, err := s.provider.GetSubscription(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.provider.GetPlan(ctx, subscription.PriceID)
+	if err != nil {
+		return nil, err
+	}
+	next := current
+	if request.PriceID != "" && request.PriceID != current.ID {
+		if next, err = s.provider.GetPlan(ctx, request.PriceID); err != nil {
+			return nil, err
+		}
+	}
+
+	at := time.Now()
+	if request.ProrationDate != nil {
+		at = *request.ProrationDate
+	}
+	return Prorate(subscription, current, next, request.Quantity, at)
+}
+
+// Cancel cancels a subscription. Cancelling at period end is announced
+// straight away; the subscription stays active until then.
+func (s *Service) Cancel(ctx context.Context, subscriptionID string, request *CancelRequest) (*stripe.Subscription, error) {
+	ctx, span := s.tracer.Start(ctx, "Cancel")
+	defer span.End()
+
+	subscription, err := s.provider.CancelSubscription(ctx, subscriptionID, request.AtPeriodEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	s.emit(ctx, EventCanceled, subscription)
+	return subscription, nil
+}
+
+// Reactivate keeps a subscription set to cancel at period end running
+func (s *Service) Reactivate(ctx context.Context, subscriptionID string) (*stripe.Subscription, error) {
+	ctx, span := s.tracer.Start(ctx, "Reactivate")
+	defer span.End()
+
+	subscription, err := s.provider.ReactivateSubscription(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.emit(ctx, EventUpdated, subscription)
+	return subscription, nil
+}
+
+// emit publishes a subscription event. The change has already been made, so
+// publishing failures are logged rather than returned.
+func (s *Service) emit(ctx context.Context, eventType string, subscription *stripe.Subscription) {
+	if s.emitter == nil {
+		return
+	}
+	if err := s.emitter.Emit(ctx, "subscriptions", eventType, subscription.ID, subscription); err != nil {
+		log.Printf("Failed to emit %s for %s: %v", eventType, subscription.ID, err)
+	}
+}
@@ -0,0 +1,95 @@
+package subscriptions
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"apis/payments/services/stripe"
+)
+
+// Event types emitted for subscription lifecycle changes made through the API
+const (
+	EventCreated  = "payments.subscription.created"
+	EventUpdated  = "payments.subscription.updated"
+	EventCanceled = "payments.subscription.canceled"
+)
+
+// ErrEmptyUpdate is returned for an update that changes nothing
+var ErrEmptyUpdate = errors.New("update requires price_id, quantity or metadata")
+
+// ErrEmptyChange is returned when previewing a change to neither price nor quantity
+var ErrEmptyChange = errors.New("preview requires price_id or quantity")
+
+// ErrInvalidQuantity is returned for a quantity below zero
+var ErrInvalidQuantity = errors.New("quantity must be positive")
+
+// ErrProrationDate is returned for a proration date outside the current period
+var ErrProrationDate = errors.New("proration_date must fall within the current billing period")
+
+// ErrCurrencyMismatch is returned when changing to a plan in another currency
+var ErrCurrencyMismatch = errors.New("plans must share a currency")
+
+// ErrInvalidStatus is returned when filtering by a status Stripe does not know
+var ErrInvalidStatus = errors.New("status must be one of active, past_due, unpaid, canceled, incomplete, incomplete_expired, trialing, paused, ended or all")
+
+// statuses are the subscription statuses a listing can be filtered by
+var statuses = map[string]bool{
+	"active":             true,
+	"past_due":           true,
+	"unpaid":             true,
+	"canceled":           true,
+	"incomplete":         true,
+	"incomplete_expired": true,
+	"trialing":           true,
+	"paused":             true,
+	"ended":              true,
+	"all":                true,
+}
+
+// ValidStatus reports whether subscriptions can be listed by status; the
+// empty status lists every subscription that has not been canceled
+func ValidStatus(status string) bool {
+	return status == "" || statuses[status]
+}
+
+// UpdateRequest moves a subscription to another price or quantity and
+// replaces its metadata. Empty values are left unchanged. Changes are
+// prorated unless prorate is false; pass a preview's proration_date to be
+// charged the previewed amount.
+type UpdateRequest struct {
+	PriceID       string            `json:"price_id,omitempty"`
+	Quantity      int64             `json:"quantity,omitempty"`
+	Prorate       *bool             `json:"prorate,omitempty"`
+	ProrationDate *time.Time        `json:"proration_date,omitempty"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+}
+
+// PreviewRequest previews moving a subscription to another price or
+// quantity, prorated at proration_date (default now)
+type PreviewRequest struct {
+	PriceID       string     `json:"price_id,omitempty"`
+	Quantity      int64      `json:"quantity,omitempty"`
+	ProrationDate *time.Time `json:"proration_date,omitempty"`
+}
+
+// CancelRequest cancels a subscription immediately or at the end of its period
+type CancelRequest struct {
+	AtPeriodEnd bool `json:"at_period_end"`
+}
+
+// Provider changes subscriptions at the payment provider
+type Provider interface {
+	CreateSubscription(ctx context.Context, request *stripe.SubscriptionRequest) (*stripe.Subscription, error)
+	GetSubscription(ctx context.Context, subscriptionID string) (*stripe.Subscription, error)
+	GetPlan(ctx context.Context, planID string) (*stripe.SubscriptionPlan, error)
+	UpdateSubscription(ctx context.Context, subscriptionID string, change *stripe.SubscriptionChange, metadata map[string]string) (*stripe.Subscription, error)
+	CancelSubscription(ctx context.Context, subscriptionID string, atPeriodEnd bool) (*stripe.Subscription, error)
+	ReactivateSubscription(ctx context.Context, subscriptionID string) (*stripe.Subscription, error)
+}
+
+// Previewer previews subscription changes at the payment provider. Changes
+// at providers that can't are previewed with Prorate.
+type Previewer interface {
+	PreviewSubscriptionChange(ctx context.Context, subscriptionID string, change *stripe.SubscriptionChange) (*stripe.ProrationPreview, error)
+}
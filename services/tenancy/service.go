@@ -0,0 +1,151 @@
+package tenancy
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// providers lists the providers a tenant can default to
+var providers = map[string]bool{"stripe": true, "paddle": true, "square": true, "paypal": true}
+
+// cachedTenant is a tenant configuration, or its absence, as of a load
+type cachedTenant struct {
+	tenant   *Tenant
+	loadedAt time.Time
+}
+
+// Service resolves the tenant behind each request and manages tenant
+// configurations
+type Service struct {
+	store  Store
+	config *Config
+	tracer trace.Tracer
+
+	mu    sync.Mutex
+	cache map[string]cachedTenant
+}
+
+// NewService creates a new tenancy service
+func NewService(store Store, config *Config) *Service {
+	if config == nil {
+		config = LoadConfig()
+	}
+
+	return &Service{
+		store:  store,
+		config: config,
+		tracer: otel.Tracer("payments.tenancy"),
+		cache:  make(map[string]cachedTenant),
+	}
+}
+
+// Resolve returns the configuration of a tenant that may make requests. The
+// default tenant needs no configuration; other tenants need one when
+// registration is required. Configurations are cached briefly.
+func (s *Service) Resolve(ctx context.Context, tenantID string) (*Tenant, error) {
+	ctx, span := s.tracer.Start(ctx, "Resolve")
+	defer span.End()
+
+	tenant, err := s.load(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if tenant == nil {
+		if s.config.RequireRegistration && tenantID != DefaultTenantID {
+			return nil, ErrUnknownTenant
+		}
+		return &Tenant{ID: tenantID, Status: StatusActive, DefaultProvider: "stripe"}, nil
+	}
+	if !tenant.Active() {
+		return nil, ErrTenantSuspended
+	}
+
+	return tenant, nil
+}
+
+// Get returns a tenant's stored configuration
+func (s *Service) Get(ctx context.Context, tenantID string) (*Tenant, error) {
+	ctx, span := s.tracer.Start(ctx, "Get")
+	defer span.End()
+
+	tenant, err := s.store.GetTenantConfig(ctx, tenantID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUnknownTenant
+	}
+	return tenant, err
+}
+
+// List returns every stored tenant configuration
+func (s *Service) List(ctx context.Context) ([]*Tenant, error) {
+	ctx, span := s.tracer.Start(ctx, "List")
+	defer span.End()
+
+	return s.store.ListTenantConfigs(ctx)
+}
+
+// Save creates or replaces a tenant's configuration, taking effect
+// immediately on this instance and within the cache TTL on others
+func (s *Service) Save(ctx context.Context, tenant *Tenant) (*Tenant, error) {
+	ctx, span := s.tracer.Start(ctx, "Save")
+	defer span.End()
+
+	if tenant.ID == "" {
+		return nil, fmt.Errorf("%w: tenant ID is required", ErrInvalidTenant)
+	}
+	if tenant.Status == "" {
+		tenant.Status = StatusActive
+	}
+	if tenant.Status != StatusActive && tenant.Status != StatusSuspended {
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidTenant, tenant.Status)
+	}
+	if tenant.DefaultProvider == "" {
+		tenant.DefaultProvider = "stripe"
+	}
+	if !providers[tenant.DefaultProvider] {
+		return nil, fmt.Errorf("%w: unknown provider %q", ErrInvalidTenant, tenant.DefaultProvider)
+	}
+
+	saved, err := s.store.UpsertTenantConfig(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	delete(s.cache, tenant.ID)
+	s.mu.Unlock()
+
+	return saved, nil
+}
+
+// load returns a tenant's configuration, or nil when it has none, from the
+// cache while it is fresh
+func (s *Service) load(ctx context.Context, tenantID string) (*Tenant, error) {
+	s.mu.Lock()
+	cached, ok := s.cache[tenantID]
+	s.mu.Unlock()
+	if ok && time.Since(cached.loadedAt) < s.config.CacheTTL {
+		return cached.tenant, nil
+	}
+
+	tenant, err := s.store.GetTenantConfig(ctx, tenantID)
+	if errors.Is(err, sql.ErrNoRows) {
+		tenant, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.cache[tenantID] = cachedTenant{tenant: tenant, loadedAt: time.Now()}
+	s.mu.Unlock()
+
+	return tenant, nil
+}
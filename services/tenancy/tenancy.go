@@ -0,0 +1,111 @@
+package tenancy
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"time"
+)
+
+// DefaultTenantID is the tenant of requests that name none. It uses the
+// platform's own provider credentials.
+const DefaultTenantID = "default"
+
+// Tenant statuses
+const (
+	StatusActive    = "active"
+	StatusSuspended = "suspended"
+)
+
+var (
+	// ErrUnknownTenant is returned for tenants without a configuration when
+	// registration is required
+	ErrUnknownTenant = errors.New("unknown tenant")
+	// ErrTenantSuspended is returned for suspended tenants
+	ErrTenantSuspended = errors.New("tenant is suspended")
+	// ErrInvalidTenant is returned when saving an invalid tenant configuration
+	ErrInvalidTenant = errors.New("invalid tenant configuration")
+	// ErrNoCredentials is returned when a tenant has no credentials for a provider
+	ErrNoCredentials = errors.New("tenant has no credentials for this provider")
+)
+
+// Tenant is a merchant's configuration. Provider credentials are kept
+// encrypted with the tenant's other provider credentials.
+type Tenant struct {
+	ID              string    `json:"id"`
+	Name            string    `json:"name"`
+	Status          string    `json:"status"`
+	DefaultProvider string    `json:"default_provider"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// Active reports whether the tenant can make requests
+func (t *Tenant) Active() bool {
+	return t.Status != StatusSuspended
+}
+
+// Config controls how tenants are resolved
+type Config struct {
+	RequireRegistration bool          // Reject tenants without a configuration
+	CacheTTL            time.Duration // How long configurations and credentials are cached
+}
+
+// LoadConfig loads the tenancy configuration from environment variables
+func LoadConfig() *Config {
+	config := &Config{
+		RequireRegistration: false,
+		CacheTTL:            time.Minute,
+	}
+
+	if required, err := strconv.ParseBool(os.Getenv("TENANT_REGISTRATION_REQUIRED")); err == nil {
+		config.RequireRegistration = required
+	}
+	if seconds, err := strconv.Atoi(os.Getenv("TENANT_CACHE_TTL_SECONDS")); err == nil && seconds >= 0 {
+		config.CacheTTL = time.Duration(seconds) * time.Second
+	}
+
+	return config
+}
+
+// Store persists tenant configurations
+type Store interface {
+	GetTenantConfig(ctx context.Context, tenantID string) (*Tenant, error)
+	ListTenantConfigs(ctx context.Context) ([]*Tenant, error)
+	UpsertTenantConfig(ctx context.Context, tenant *Tenant) (*Tenant, error)
+}
+
+// tenantKey is the context key of the request's tenant
+type tenantKey struct{}
+
+// WithTenant returns a context acting for a tenant
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// FromContext returns the tenant a context acts for. Contexts of webhooks and
+// workers act for no tenant, and see every tenant's records.
+func FromContext(ctx context.Context) (string, bool) {
+	tenantID, ok := ctx.Value(tenantKey{}).(string)
+	return tenantID, ok && tenantID != ""
+}
+
+// ID returns the tenant a context acts for, or the default tenant
+func ID(ctx context.Context) string {
+	if tenantID, ok := FromContext(ctx); ok {
+		return tenantID
+	}
+	return DefaultTenantID
+}
+
+// ValueSetter stores request-scoped values, such as a *fasthttp.RequestCtx,
+// whose values are then visible through its context.Context
+type ValueSetter interface {
+	SetUserValue(key, value any)
+}
+
+// Bind makes a request's context act for a tenant
+func Bind(request ValueSetter, tenantID string) {
+	request.SetUserValue(tenantKey{}, tenantID)
+}
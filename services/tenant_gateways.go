@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"apis/payments/services/resilience"
+	"apis/payments/services/simulator"
+	"apis/payments/services/stripe"
+	"apis/payments/services/tenancy"
+)
+
+// CredentialSource resolves a tenant's decrypted provider credentials
+type CredentialSource interface {
+	Resolve(ctx context.Context, tenantID, provider string) (map[string]string, error)
+}
+
+// cachedCredentials are a tenant's credentials for a provider and the
+// gateway built from them
+type cachedCredentials struct {
+	fields   map[string]string
+	gateway  PaymentGateway
+	loadedAt time.Time
+}
+
+// TenantGateways scopes provider access by tenant. The default tenant uses
+// the platform credentials from the environment; every other tenant only
+// ever uses the credentials it stored, so its calls reach its own provider
+// account. Credentials are cached for ttl, or until invalidated.
+type TenantGateways struct {
+	credentials CredentialSource
+	ttl         time.Duration
+
+	mu         sync.Mutex
+	cache      map[string]*cachedCredentials
+	resilience *resilience.Policy
+}
+
+// NewTenantGateways creates per-tenant gateways over a credential source
+func NewTenantGateways(credentials CredentialSource, ttl time.Duration) *TenantGateways {
+	return &TenantGateways{
+		credentials: credentials,
+		ttl:         ttl,
+		cache:       make(map[string]*cachedCredentials),
+	}
+}
+
+// UseResilience wraps the gateways returned from then on in a policy's
+// retries and circuit breakers, shared by every tenant
+func (g *TenantGateways) UseResilience(policy *resilience.Policy) {
+	g.mu.Lock()
+	g.resilience = policy
+	g.mu.Unlock()
+}
+
+// Credentials returns the credentials a tenant's calls to a provider use
+func (g *TenantGateways) Credentials(ctx context.Context, tenantID, provider string) (map[string]string, error) {
+	entry, err := g.load(ctx, tenantID, provider)
+	if err != nil {
+		return nil, err
+	}
+	return entry.fields, nil
+}
+
+// Gateway returns a gateway acting on a tenant's provider account. Stripe
+// gateways share the process-wide client, which picks each call's key from
+// the tenant its context acts for, so they must be called with that context.
+func (g *TenantGateways) Gateway(ctx context.Context, tenantID, provider string) (PaymentGateway, error) {
+	entry, err := g.load(ctx, tenantID, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if entry.gateway != nil {
+		return entry.gateway, nil
+	}
+
+	if provider == "stripe" {
+		entry.gateway = NewStripeGatewayWithServices(
+			stripe.NewCustomerService(),
+			stripe.NewChargeService(),
+			stripe.NewRefundService(),
+			stripe.NewSubscriptionService(),
+			stripe.NewDisputeService(),
+			stripe.NewInvoiceService(),
+			stripe.NewConnectService(),
+			stripe.NewPaymentLinkService(),
+		)
+		entry.gateway = g.decorate(entry.gateway)
+		return entry.gateway, nil
+	}
+
+	config := make(map[string]interface{}, len(entry.fields))
+	for key, value := range entry.fields {
+		config[key] = value
+	}
+	gateway, err := GetFactory().CreateGateway(provider, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s gateway for tenant %s: %w", provider, tenantID, err)
+	}
+	entry.gateway = g.decorate(gateway)
+
+	return entry.gateway, nil
+}
+
+// decorate wraps a gateway in the resilience policy, if one is used. The
+// caller holds g.mu.
+func (g *TenantGateways) decorate(gateway PaymentGateway) PaymentGateway {
+	if g.resilience == nil {
+		return gateway
+	}
+	return NewResilientGateway(gateway, g.resilience)
+}
+
+// SecretKey returns the Stripe key for calls made with a context acting for
+// a tenant other than the default one
+func (g *TenantGateways) SecretKey(ctx context.Context) (string, bool, error) {
+	tenantID, ok := tenancy.FromContext(ctx)
+	if !ok || tenantID == tenancy.DefaultTenantID {
+		return "", false, nil
+	}
+
+	fields, err := g.Credentials(ctx, tenantID, "stripe")
+	if err != nil {
+		return "", false, err
+	}
+	return fields["api_key"], true, nil
+}
+
+// Invalidate drops a tenant's cached credentials for a provider, e.g. after
+// they are rotated
+func (g *TenantGateways) Invalidate(tenantID, provider string) {
+	g.mu.Lock()
+	delete(g.cache, tenantID+"/"+provider)
+	g.mu.Unlock()
+}
+
+// load returns a tenant's credentials for a provider from the cache while
+// they are fresh
+func (g *TenantGateways) load(ctx context.Context, tenantID, provider string) (*cachedCredentials, error) {
+	key := tenantID + "/" + provider
+
+	g.mu.Lock()
+	entry, ok := g.cache[key]
+	g.mu.Unlock()
+	if ok && time.Since(entry.loadedAt) < g.ttl {
+		return entry, nil
+	}
+
+	var fields map[string]string
+	if provider == simulator.Provider {
+		// The simulator has no accounts to reach, so needs no credentials
+		fields = make(map[string]string)
+	} else if tenantID == tenancy.DefaultTenantID {
+		fields = make(map[string]string)
+		for name, value := range buildConfigFromEnv(provider) {
+			if s, ok := value.(string); ok && s != "" {
+				fields[name] = s
+			}
+		}
+	} else {
+		var err error
+		fields, err = g.credentials.Resolve(ctx, tenantID, provider)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %s", tenancy.ErrNoCredentials, provider)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	entry = &cachedCredentials{fields: fields, loadedAt: time.Now()}
+	g.mu.Lock()
+	g.cache[key] = entry
+	g.mu.Unlock()
+
+	return entry, nil
+}
@@ -0,0 +1,81 @@
+package tenantcredentials
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+)
+
+// Cipher encrypts credentials with AES-256-GCM. Ciphertexts are bound to the
+// tenant and provider they belong to, so a stored value cannot be swapped
+// onto another tenant's row.
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// NewCipher creates a cipher from a 32-byte key
+func NewCipher(key []byte) (*Cipher, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("credential encryption key must be 32 bytes, got %d", len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	return &Cipher{aead: aead}, nil
+}
+
+// LoadCipher reads the base64-encoded key from CREDENTIALS_ENCRYPTION_KEY. It
+// returns ErrEncryptionNotConfigured when the key is not set.
+func LoadCipher() (*Cipher, error) {
+	encoded := os.Getenv("CREDENTIALS_ENCRYPTION_KEY")
+	if encoded == "" {
+		return nil, ErrEncryptionNotConfigured
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CREDENTIALS_ENCRYPTION_KEY: %w", err)
+	}
+
+	return NewCipher(key)
+}
+
+// Seal encrypts plaintext for a tenant's provider credentials
+func (c *Cipher) Seal(tenantID, provider string, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	return c.aead.Seal(nonce, nonce, plaintext, additionalData(tenantID, provider)), nil
+}
+
+// Open decrypts a tenant's provider credentials
+func (c *Cipher) Open(tenantID, provider string, ciphertext []byte) ([]byte, error) {
+	size := c.aead.NonceSize()
+	if len(ciphertext) < size {
+		return nil, fmt.Errorf("failed to decrypt credentials: ciphertext too short")
+	}
+
+	plaintext, err := c.aead.Open(nil, ciphertext[:size], ciphertext[size:], additionalData(tenantID, provider))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt credentials: %w", err)
+	}
+
+	return plaintext, nil
+}
+
+// additionalData binds a ciphertext to its tenant and provider
+func additionalData(tenantID, provider string) []byte {
+	return []byte(tenantID + "\x00" + provider)
+}
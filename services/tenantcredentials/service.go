@@ -0,0 +1,330 @@
+package tenantcredentials
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Store persists encrypted provider credentials and their audit trail
+type Store interface {
+	GetProviderCredential(ctx context.Context, tenantID, provider string) (*Record, error)
+	ListProviderCredentials(ctx context.Context, tenantID string) ([]*Record, error)
+	UpsertProviderCredential(ctx context.Context, record *Record) (*Record, error)
+	DeleteProviderCredential(ctx context.Context, tenantID, provider string) (bool, error)
+	CreateProviderCredentialAudit(ctx context.Context, entry *AuditEntry) error
+	ListProviderCredentialAudit(ctx context.Context, tenantID, provider string, limit int) ([]*AuditEntry, error)
+}
+
+// Service manages the provider credentials tenants bring themselves. Fields
+// are verified with a test call before they are stored encrypted, reads
+// return secret fields masked, and every change is audited.
+type Service struct {
+	store    Store
+	cipher   *Cipher
+	verifier Verifier
+	tracer   trace.Tracer
+}
+
+// NewService creates a new credential service. Without a cipher, masked
+// credentials can be read but none can be stored or resolved.
+func NewService(store Store, cipher *Cipher, verifier Verifier) *Service {
+	return &Service{
+		store:    store,
+		cipher:   cipher,
+		verifier: verifier,
+		tracer:   otel.Tracer("payments.tenantcredentials"),
+	}
+}
+
+// Get returns a tenant's credentials for a provider with secrets masked
+func (s *Service) Get(ctx context.Context, tenantID, provider string) (*Credential, error) {
+	ctx, span := s.tracer.Start(ctx, "Get")
+	defer span.End()
+
+	record, err := s.store.GetProviderCredential(ctx, tenantID, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	return masked(record), nil
+}
+
+// List returns every provider credential a tenant has stored, with secrets masked
+func (s *Service) List(ctx context.Context, tenantID string) ([]*Credential, error) {
+	ctx, span := s.tracer.Start(ctx, "List")
+	defer span.End()
+
+	records, err := s.store.ListProviderCredentials(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials := make([]*Credential, len(records))
+	for i, record := range records {
+		credentials[i] = masked(record)
+	}
+	return credentials, nil
+}
+
+// Save verifies and stores a tenant's credentials for a provider, replacing
+// any stored ones
+func (s *Service) Save(ctx context.Context, actor Actor, tenantID, provider string, fields map[string]string) (*Credential, error) {
+	ctx, span := s.tracer.Start(ctx, "Save")
+	defer span.End()
+
+	if s.cipher == nil {
+		return nil, ErrEncryptionNotConfigured
+	}
+
+	existing, err := s.store.GetProviderCredential(ctx, tenantID, provider)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get credentials: %w", err)
+	}
+
+	action := ActionCreated
+	var previous map[string]string
+	if existing != nil {
+		action = ActionUpdated
+		if previous, err = s.open(existing); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.persist(ctx, actor, action, existing, previous, tenantID, provider, fields)
+}
+
+// Rotate replaces some fields of a tenant's stored credentials, typically a
+// new secret key, keeping the rest. The merged credentials are verified
+// before the old ones are replaced.
+func (s *Service) Rotate(ctx context.Context, actor Actor, tenantID, provider string, fields map[string]string) (*Credential, error) {
+	ctx, span := s.tracer.Start(ctx, "Rotate")
+	defer span.End()
+
+	if s.cipher == nil {
+		return nil, ErrEncryptionNotConfigured
+	}
+
+	spec, ok := providers[provider]
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported provider %q", ErrInvalidCredentials, provider)
+	}
+
+	rotatesSecret := false
+	for key := range fields {
+		rotatesSecret = rotatesSecret || spec.secret[key]
+	}
+	if !rotatesSecret {
+		return nil, fmt.Errorf("%w: rotation must replace a secret field", ErrInvalidCredentials)
+	}
+
+	existing, err := s.store.GetProviderCredential(ctx, tenantID, provider)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := s.open(existing)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := make(map[string]string, len(previous)+len(fields))
+	for key, value := range previous {
+		merged[key] = value
+	}
+	for key, value := range fields {
+		merged[key] = value
+	}
+	for key := range fields {
+		if spec.secret[key] && previous[key] == fields[key] {
+			return nil, fmt.Errorf("%w: %s must differ from the current value", ErrInvalidCredentials, key)
+		}
+	}
+
+	return s.persist(ctx, actor, ActionRotated, existing, previous, tenantID, provider, merged)
+}
+
+// Delete removes a tenant's credentials for a provider, reporting whether any were stored
+func (s *Service) Delete(ctx context.Context, actor Actor, tenantID, provider string) (bool, error) {
+	ctx, span := s.tracer.Start(ctx, "Delete")
+	defer span.End()
+
+	existing, err := s.store.GetProviderCredential(ctx, tenantID, provider)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get credentials: %w", err)
+	}
+
+	deleted, err := s.store.DeleteProviderCredential(ctx, tenantID, provider)
+	if err != nil || !deleted {
+		return false, err
+	}
+
+	fields := make([]string, 0, len(existing.MaskedFields))
+	for key := range existing.MaskedFields {
+		fields = append(fields, key)
+	}
+	sort.Strings(fields)
+
+	if err := s.audit(ctx, actor, ActionDeleted, tenantID, provider, existing.Version, fields); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// Resolve returns a tenant's decrypted credentials for a provider, for
+// building a gateway that acts on the tenant's own provider account
+func (s *Service) Resolve(ctx context.Context, tenantID, provider string) (map[string]string, error) {
+	ctx, span := s.tracer.Start(ctx, "Resolve")
+	defer span.End()
+
+	if s.cipher == nil {
+		return nil, ErrEncryptionNotConfigured
+	}
+
+	record, err := s.store.GetProviderCredential(ctx, tenantID, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.open(record)
+}
+
+// Audit returns the most recent changes to a tenant's credentials for a provider
+func (s *Service) Audit(ctx context.Context, tenantID, provider string, limit int) ([]*AuditEntry, error) {
+	ctx, span := s.tracer.Start(ctx, "Audit")
+	defer span.End()
+
+	if limit <= 0 || limit > 100 {
+		limit = 100
+	}
+	return s.store.ListProviderCredentialAudit(ctx, tenantID, provider, limit)
+}
+
+// persist validates, verifies, encrypts and stores credentials, then audits the change
+func (s *Service) persist(ctx context.Context, actor Actor, action string, existing *Record, previous map[string]string, tenantID, provider string, fields map[string]string) (*Credential, error) {
+	if err := validate(provider, fields); err != nil {
+		return nil, err
+	}
+
+	if err := s.verifier.Verify(ctx, provider, fields); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrVerificationFailed, err)
+	}
+
+	plaintext, err := json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode credentials: %w", err)
+	}
+	ciphertext, err := s.cipher.Seal(tenantID, provider, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := providers[provider]
+	maskedFields := make(map[string]string, len(fields))
+	for key, value := range fields {
+		if spec.secret[key] {
+			value = Mask(value)
+		}
+		maskedFields[key] = value
+	}
+
+	version := 1
+	if existing != nil {
+		version = existing.Version + 1
+	}
+
+	record, err := s.store.UpsertProviderCredential(ctx, &Record{
+		TenantID:     tenantID,
+		Provider:     provider,
+		Ciphertext:   ciphertext,
+		MaskedFields: maskedFields,
+		Version:      version,
+		VerifiedAt:   time.Now(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store credentials: %w", err)
+	}
+
+	if err := s.audit(ctx, actor, action, tenantID, provider, version, changedFields(previous, fields)); err != nil {
+		return nil, err
+	}
+
+	return masked(record), nil
+}
+
+// audit records a change to a tenant's credentials
+func (s *Service) audit(ctx context.Context, actor Actor, action, tenantID, provider string, version int, changed []string) error {
+	entry := &AuditEntry{
+		ID:            "cra_" + uuid.New().String(),
+		TenantID:      tenantID,
+		Provider:      provider,
+		Action:        action,
+		Version:       version,
+		ChangedFields: changed,
+		APIKeyID:      actor.APIKeyID,
+		OperatorID:    actor.OperatorID,
+	}
+
+	if err := s.store.CreateProviderCredentialAudit(ctx, entry); err != nil {
+		return fmt.Errorf("failed to audit credential change: %w", err)
+	}
+	return nil
+}
+
+// open decrypts a stored credential's fields
+func (s *Service) open(record *Record) (map[string]string, error) {
+	plaintext, err := s.cipher.Open(record.TenantID, record.Provider, record.Ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]string
+	if err := json.Unmarshal(plaintext, &fields); err != nil {
+		return nil, fmt.Errorf("failed to decode credentials: %w", err)
+	}
+	return fields, nil
+}
+
+// validate checks that credentials have the provider's required fields and no others
+func validate(provider string, fields map[string]string) error {
+	spec, ok := providers[provider]
+	if !ok {
+		return fmt.Errorf("%w: unsupported provider %q", ErrInvalidCredentials, provider)
+	}
+
+	for _, key := range spec.required {
+		if fields[key] == "" {
+			return fmt.Errorf("%w: %s is required", ErrInvalidCredentials, key)
+		}
+	}
+	for key := range fields {
+		if !spec.secret[key] && !spec.plain[key] {
+			return fmt.Errorf("%w: %s is not a %s field", ErrInvalidCredentials, key, provider)
+		}
+	}
+
+	return nil
+}
+
+// masked converts a stored credential to its masked API representation
+func masked(record *Record) *Credential {
+	return &Credential{
+		TenantID:   record.TenantID,
+		Provider:   record.Provider,
+		Fields:     record.MaskedFields,
+		Version:    record.Version,
+		VerifiedAt: record.VerifiedAt,
+		CreatedAt:  record.CreatedAt,
+		UpdatedAt:  record.UpdatedAt,
+	}
+}
@@ -0,0 +1,151 @@
+package tenantcredentials
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Audit actions
+const (
+	ActionCreated = "created"
+	ActionUpdated = "updated"
+	ActionRotated = "rotated"
+	ActionDeleted = "deleted"
+)
+
+// ErrInvalidCredentials is returned for credentials with unknown or missing fields
+var ErrInvalidCredentials = errors.New("invalid provider credentials")
+
+// ErrVerificationFailed is returned when the provider rejects a test call
+// made with the credentials
+var ErrVerificationFailed = errors.New("provider rejected the credentials")
+
+// ErrEncryptionNotConfigured is returned when no encryption key is configured
+var ErrEncryptionNotConfigured = errors.New("credential encryption key is not configured")
+
+// fieldSet lists the fields a provider's credentials accept. Secret fields
+// are encrypted and only ever returned masked.
+type fieldSet struct {
+	required []string
+	secret   map[string]bool
+	plain    map[string]bool
+}
+
+// providers lists the fields each supported provider accepts
+var providers = map[string]fieldSet{
+	"stripe": {
+		required: []string{"api_key"},
+		secret:   map[string]bool{"api_key": true, "webhook_secret": true},
+		plain:    map[string]bool{"publishable_key": true},
+	},
+	"paddle": {
+		required: []string{"api_key", "environment"},
+		secret:   map[string]bool{"api_key": true, "webhook_secret": true},
+		plain:    map[string]bool{"environment": true},
+	},
+	"square": {
+		required: []string{"application_id", "access_token", "environment"},
+		secret:   map[string]bool{"access_token": true},
+		plain:    map[string]bool{"application_id": true, "location_id": true, "environment": true},
+	},
+	"paypal": {
+		required: []string{"client_id", "client_secret", "environment"},
+		secret:   map[string]bool{"client_secret": true},
+		plain:    map[string]bool{"client_id": true, "webhook_id": true, "environment": true},
+	},
+}
+
+// Credential is a tenant's credentials for a provider, as returned by the
+// API with secret fields masked
+type Credential struct {
+	TenantID   string            `json:"tenant_id"`
+	Provider   string            `json:"provider"`
+	Fields     map[string]string `json:"fields"`
+	Version    int               `json:"version"` // Incremented on every change
+	VerifiedAt time.Time         `json:"verified_at"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
+
+// Record is a credential as stored: every field encrypted together, and
+// masked copies for display
+type Record struct {
+	TenantID     string
+	Provider     string
+	Ciphertext   []byte
+	MaskedFields map[string]string
+	Version      int
+	VerifiedAt   time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// AuditEntry records a change to a tenant's credentials. It names the fields
+// that changed, never their values.
+type AuditEntry struct {
+	ID            string    `json:"id"`
+	TenantID      string    `json:"tenant_id"`
+	Provider      string    `json:"provider"`
+	Action        string    `json:"action"`
+	Version       int       `json:"version"`
+	ChangedFields []string  `json:"changed_fields"`
+	APIKeyID      string    `json:"api_key_id,omitempty"`
+	OperatorID    string    `json:"operator_id,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Actor identifies who changed a credential
+type Actor struct {
+	APIKeyID   string
+	OperatorID string
+}
+
+// Verifier makes an authenticated test call to a provider with credentials
+type Verifier interface {
+	Verify(ctx context.Context, provider string, fields map[string]string) error
+}
+
+// Providers returns the providers credentials can be stored for
+func Providers() []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Mask hides all but a secret's recognizable prefix, such as "sk_live_", and
+// its last four characters
+func Mask(value string) string {
+	if len(value) <= 8 {
+		return strings.Repeat("*", len(value))
+	}
+
+	prefix := ""
+	if i := strings.LastIndex(value[:len(value)-4], "_"); i >= 0 && i < 12 {
+		prefix = value[:i+1]
+	}
+
+	return prefix + "****" + value[len(value)-4:]
+}
+
+// changedFields returns the sorted names of fields whose values differ
+func changedFields(before, after map[string]string) []string {
+	var changed []string
+	for key, value := range after {
+		if previous, ok := before[key]; !ok || previous != value {
+			changed = append(changed, key)
+		}
+	}
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
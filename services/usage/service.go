@@ -0,0 +1,231 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"apis/payments/services/stripe"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Service records metered usage locally, pushes it to the provider and
+// totals it for consumption queries
+type Service struct {
+	store     Store
+	provider  Provider
+	config    *Config
+	validator *validator.Validate
+	tracer    trace.Tracer
+}
+
+// NewService creates a new usage service
+func NewService(store Store, provider Provider, config *Config) *Service {
+	if config == nil {
+		config = LoadConfig()
+	}
+
+	return &Service{
+		store:     store,
+		provider:  provider,
+		config:    config,
+		validator: validator.New(),
+		tracer:    otel.Tracer("payments.usage"),
+	}
+}
+
+// Report records usage of a subscription's metered price and pushes it to
+// the provider. Usage is added to sum prices and set on max and last prices.
+// A record that fails to push is kept and retried. Reports repeating an
+// idempotency key return the first report.
+func (s *Service) Report(ctx context.Context, subscriptionID string, request *ReportRequest) (*Record, error) {
+	ctx, span := s.tracer.Start(ctx, "Report")
+	defer span.End()
+
+	if err := s.validator.Struct(request); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidUsage, err)
+	}
+
+	if request.IdempotencyKey != "" {
+		if existing, err := s.store.GetUsageRecordByKey(ctx, subscriptionID, request.IdempotencyKey); err == nil {
+			return existing, nil
+		}
+	}
+
+	item, err := s.provider.MeteredItem(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	recordedAt := now
+	if request.Timestamp != nil {
+		recordedAt = *request.Timestamp
+	}
+	if recordedAt.After(now) {
+		return nil, fmt.Errorf("%w: timestamp is in the future", ErrInvalidUsage)
+	}
+	if recordedAt.Before(time.Unix(item.CurrentPeriodStart, 0)) {
+		return nil, fmt.Errorf("%w: timestamp is before the current billing period", ErrInvalidUsage)
+	}
+
+	action := stripe.UsageActionSet
+	if aggregations[item.AggregateUsage] == AggregationSum {
+		action = stripe.UsageActionIncrement
+	}
+
+	record := &Record{
+		ID:                 fmt.Sprintf("ur_%s", uuid.New().String()),
+		SubscriptionID:     subscriptionID,
+		SubscriptionItemID: item.ID,
+		PriceID:            item.PriceID,
+		Quantity:           request.Quantity,
+		Action:             action,
+		RecordedAt:         recordedAt.UTC(),
+		IdempotencyKey:     request.IdempotencyKey,
+	}
+	inserted, err := s.store.InsertUsageRecord(ctx, record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record usage: %w", err)
+	}
+	// A concurrent report with the same key got there first
+	if !inserted {
+		return s.store.GetUsageRecordByKey(ctx, subscriptionID, request.IdempotencyKey)
+	}
+
+	pushed, err := s.push(ctx, record)
+	if err != nil {
+		log.Printf("Failed to push usage record %s, will retry: %v", record.ID, err)
+		return s.store.GetUsageRecord(ctx, record.ID)
+	}
+
+	return pushed, nil
+}
+
+// Consumption totals a subscription's usage over a window, including usage
+// not yet pushed to the provider
+func (s *Service) Consumption(ctx context.Context, subscriptionID string, window Window) (*Consumption, error) {
+	ctx, span := s.tracer.Start(ctx, "Consumption")
+	defer span.End()
+
+	if window.Aggregation != "" && !ValidAggregation(window.Aggregation) {
+		return nil, fmt.Errorf("%w: aggregation must be one of sum, max, last", ErrInvalidUsage)
+	}
+
+	item, err := s.provider.MeteredItem(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	consumption := &Consumption{
+		SubscriptionID:     subscriptionID,
+		SubscriptionItemID: item.ID,
+		PriceID:            item.PriceID,
+		Aggregation:        window.Aggregation,
+		From:               time.Unix(item.CurrentPeriodStart, 0).UTC(),
+		To:                 time.Unix(item.CurrentPeriodEnd, 0).UTC(),
+	}
+	if consumption.Aggregation == "" {
+		consumption.Aggregation = aggregations[item.AggregateUsage]
+	}
+	if window.From != nil {
+		consumption.From = window.From.UTC()
+	}
+	if window.To != nil {
+		consumption.To = window.To.UTC()
+	}
+	if !consumption.To.After(consumption.From) {
+		return nil, fmt.Errorf("%w: to must be after from", ErrInvalidUsage)
+	}
+
+	records, err := s.store.ListUsageRecords(ctx, subscriptionID, consumption.From, consumption.To)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list usage records: %w", err)
+	}
+
+	consumption.Records = records
+	consumption.Quantity = Aggregate(records, consumption.Aggregation)
+	for _, record := range records {
+		if record.ReportedAt == nil {
+			consumption.Unreported++
+		}
+	}
+
+	return consumption, nil
+}
+
+// RetryPending pushes records that failed to push, and returns how many were pushed
+func (s *Service) RetryPending(ctx context.Context) (int, error) {
+	ctx, span := s.tracer.Start(ctx, "RetryPending")
+	defer span.End()
+
+	records, err := s.store.ListUnreportedUsageRecords(ctx, s.config.MaxAttempts, s.config.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list unreported usage records: %w", err)
+	}
+
+	pushed := 0
+	for _, record := range records {
+		if _, err := s.push(ctx, record); err != nil {
+			log.Printf("Failed to push usage record %s: %v", record.ID, err)
+			continue
+		}
+		pushed++
+	}
+
+	return pushed, nil
+}
+
+// Start retries records that failed to push every configured interval
+// until the returned stop function is called
+func (s *Service) Start() (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(s.config.ReportInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := s.RetryPending(context.Background()); err != nil {
+					log.Printf("Usage record retry run failed: %v", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// push sends a record to the provider. The record ID is the idempotency
+// key, so a push that succeeded but was not marked is not counted twice.
+func (s *Service) push(ctx context.Context, record *Record) (*Record, error) {
+	providerRecord, err := s.provider.ReportUsage(ctx, &stripe.UsageRecordRequest{
+		SubscriptionItemID: record.SubscriptionItemID,
+		Quantity:           record.Quantity,
+		Timestamp:          record.RecordedAt.Unix(),
+		Action:             record.Action,
+		IdempotencyKey:     record.ID,
+	})
+	if err != nil {
+		if failErr := s.store.RecordUsageReportFailure(ctx, record.ID, err.Error()); failErr != nil {
+			log.Printf("Failed to record push failure of usage record %s: %v", record.ID, failErr)
+		}
+		return nil, err
+	}
+
+	return s.store.MarkUsageRecordReported(ctx, record.ID, providerRecord.ID, time.Now())
+}
@@ -0,0 +1,133 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"apis/payments/services/config"
+	"apis/payments/services/stripe"
+)
+
+// Aggregations total the usage records of a window
+const (
+	AggregationSum  = "sum"  // Total of every record
+	AggregationMax  = "max"  // Largest record
+	AggregationLast = "last" // Latest record
+)
+
+// aggregations maps the provider's aggregate_usage to how records are totalled
+var aggregations = map[string]string{
+	"sum":                AggregationSum,
+	"max":                AggregationMax,
+	"last_during_period": AggregationLast,
+	"last_ever":          AggregationLast,
+}
+
+// ErrInvalidUsage is returned for usage that cannot be recorded or totalled
+var ErrInvalidUsage = errors.New("invalid usage")
+
+// Config controls pushing usage records to the provider
+type Config struct {
+	ReportInterval time.Duration // How often records that failed to push are retried
+	MaxAttempts    int           // Pushes after which a record is given up on
+	BatchSize      int
+}
+
+// LoadConfig loads usage configuration from environment variables
+func LoadConfig() *Config {
+	return &Config{
+		ReportInterval: time.Duration(config.Int("USAGE_REPORT_INTERVAL_MINUTES", 5)) * time.Minute,
+		MaxAttempts:    config.Int("USAGE_REPORT_MAX_ATTEMPTS", 5),
+		BatchSize:      config.Int("USAGE_REPORT_BATCH_SIZE", 100),
+	}
+}
+
+// Record is a usage report for a subscription's metered item
+type Record struct {
+	ID                 string     `json:"id"`
+	SubscriptionID     string     `json:"subscription_id"`
+	SubscriptionItemID string     `json:"subscription_item_id"`
+	PriceID            string     `json:"price_id,omitempty"`
+	Quantity           int64      `json:"quantity"`
+	Action             string     `json:"action"` // increment or set, from the price's aggregation
+	RecordedAt         time.Time  `json:"recorded_at"`
+	IdempotencyKey     string     `json:"idempotency_key,omitempty"`
+	ProviderRecordID   string     `json:"provider_record_id,omitempty"`
+	ReportedAt         *time.Time `json:"reported_at,omitempty"` // Unset until pushed to the provider
+	Attempts           int        `json:"attempts"`
+	LastError          string     `json:"last_error,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+}
+
+// ReportRequest reports a quantity used
+type ReportRequest struct {
+	Quantity       int64      `json:"quantity" validate:"gte=0"`
+	Timestamp      *time.Time `json:"timestamp,omitempty"` // When the usage happened, defaults to now
+	IdempotencyKey string     `json:"-"`                   // From the Idempotency-Key header
+}
+
+// Window selects the usage to total. Unset bounds default to the current
+// billing period and an unset aggregation to the price's.
+type Window struct {
+	From        *time.Time
+	To          *time.Time
+	Aggregation string
+}
+
+// Consumption is the usage of a subscription over a window
+type Consumption struct {
+	SubscriptionID     string    `json:"subscription_id"`
+	SubscriptionItemID string    `json:"subscription_item_id"`
+	PriceID            string    `json:"price_id"`
+	Aggregation        string    `json:"aggregation"`
+	From               time.Time `json:"from"`
+	To                 time.Time `json:"to"`
+	Quantity           int64     `json:"quantity"`
+	Unreported         int       `json:"unreported"` // Records not yet pushed to the provider
+	Records            []*Record `json:"records"`
+}
+
+// Store persists usage records
+type Store interface {
+	InsertUsageRecord(ctx context.Context, record *Record) (bool, error)
+	GetUsageRecord(ctx context.Context, id string) (*Record, error)
+	GetUsageRecordByKey(ctx context.Context, subscriptionID, idempotencyKey string) (*Record, error)
+	ListUsageRecords(ctx context.Context, subscriptionID string, from, to time.Time) ([]*Record, error)
+	ListUnreportedUsageRecords(ctx context.Context, maxAttempts, limit int) ([]*Record, error)
+	MarkUsageRecordReported(ctx context.Context, id, providerRecordID string, reportedAt time.Time) (*Record, error)
+	RecordUsageReportFailure(ctx context.Context, id, message string) error
+}
+
+// Provider looks up metered subscription items and records their usage
+type Provider interface {
+	MeteredItem(ctx context.Context, subscriptionID string) (*stripe.MeteredItem, error)
+	ReportUsage(ctx context.Context, request *stripe.UsageRecordRequest) (*stripe.UsageRecord, error)
+}
+
+// Aggregate totals records, which must be in the order they were used
+func Aggregate(records []*Record, aggregation string) int64 {
+	var total int64
+	for i, record := range records {
+		switch aggregation {
+		case AggregationSum:
+			total += record.Quantity
+		case AggregationMax:
+			if i == 0 || record.Quantity > total {
+				total = record.Quantity
+			}
+		case AggregationLast:
+			total = record.Quantity
+		}
+	}
+	return total
+}
+
+// ValidAggregation reports whether records can be totalled by aggregation
+func ValidAggregation(aggregation string) bool {
+	switch aggregation {
+	case AggregationSum, AggregationMax, AggregationLast:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package vault
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Service issues vault tokens and resolves them to provider tokens
+type Service struct {
+	store  Store
+	tracer trace.Tracer
+}
+
+// NewService creates a new vault service
+func NewService(store Store) *Service {
+	return &Service{
+		store:  store,
+		tracer: otel.Tracer("payments.vault"),
+	}
+}
+
+// Issue returns the vault token for a provider payment method, creating one
+// the first time the provider token is seen
+func (s *Service) Issue(ctx context.Context, token *Token) (*Token, error) {
+	ctx, span := s.tracer.Start(ctx, "Issue")
+	defer span.End()
+
+	if token.Provider == "" {
+		return nil, fmt.Errorf("provider cannot be empty")
+	}
+	if token.ProviderToken == "" {
+		return nil, fmt.Errorf("provider token cannot be empty")
+	}
+
+	existing, err := s.store.GetVaultTokenByProviderToken(ctx, token.Provider, token.ProviderToken)
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	token.ID = fmt.Sprintf("%s%s", TokenPrefix, uuid.New().String())
+	return s.store.CreateVaultToken(ctx, token)
+}
+
+// Get retrieves a vault token
+func (s *Service) Get(ctx context.Context, id string) (*Token, error) {
+	ctx, span := s.tracer.Start(ctx, "Get")
+	defer span.End()
+
+	if !IsToken(id) {
+		return nil, ErrTokenNotFound
+	}
+
+	token, err := s.store.GetVaultToken(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTokenNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// Resolve returns the provider and provider token behind an identifier.
+// Identifiers that are not vault tokens are returned unchanged so callers
+// holding provider tokens from before the vault keep working.
+func (s *Service) Resolve(ctx context.Context, id, defaultProvider string) (string, string, error) {
+	ctx, span := s.tracer.Start(ctx, "Resolve")
+	defer span.End()
+
+	if !IsToken(id) {
+		return defaultProvider, id, nil
+	}
+
+	token, err := s.Get(ctx, id)
+	if err != nil {
+		return "", "", err
+	}
+
+	return token.Provider, token.ProviderToken, nil
+}
+
+// ListForCustomer returns every vault token issued for a customer
+func (s *Service) ListForCustomer(ctx context.Context, customerID string) ([]*Token, error) {
+	ctx, span := s.tracer.Start(ctx, "ListForCustomer")
+	defer span.End()
+
+	if customerID == "" {
+		return nil, fmt.Errorf("customer ID cannot be empty")
+	}
+
+	return s.store.ListVaultTokensByCustomer(ctx, customerID)
+}
+
+// Remap points a vault token at a payment method held by another provider,
+// e.g. after migrating cards. The token ID callers hold does not change.
+func (s *Service) Remap(ctx context.Context, id, provider, providerToken string) (*Token, error) {
+	ctx, span := s.tracer.Start(ctx, "Remap")
+	defer span.End()
+
+	if provider == "" {
+		return nil, fmt.Errorf("provider cannot be empty")
+	}
+	if providerToken == "" {
+		return nil, fmt.Errorf("provider token cannot be empty")
+	}
+
+	if _, err := s.Get(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return s.store.RemapVaultToken(ctx, id, provider, providerToken)
+}
+
+// UpdateFingerprint keeps the fingerprint of the token for a provider's
+// payment method current, e.g. after its card was reissued. Payment methods
+// without a token are ignored.
+func (s *Service) UpdateFingerprint(ctx context.Context, provider, providerToken, fingerprint string) error {
+	ctx, span := s.tracer.Start(ctx, "UpdateFingerprint")
+	defer span.End()
+
+	return s.store.UpdateVaultTokenFingerprint(ctx, provider, providerToken, fingerprint)
+}
+
+// Revoke deletes a vault token once its payment method is detached
+func (s *Service) Revoke(ctx context.Context, id string) error {
+	ctx, span := s.tracer.Start(ctx, "Revoke")
+	defer span.End()
+
+	return s.store.DeleteVaultToken(ctx, id)
+}
@@ -0,0 +1,44 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// TokenPrefix identifies provider-agnostic payment method tokens
+const TokenPrefix = "pmt_"
+
+// ErrTokenNotFound is returned when a vault token does not exist
+var ErrTokenNotFound = errors.New("payment method token not found")
+
+// Token maps one of our payment method tokens to the provider that holds the
+// payment method. Callers only ever see the ID; the provider side can be
+// remapped when a payment method is routed or migrated elsewhere.
+type Token struct {
+	ID            string    `json:"id"`
+	Provider      string    `json:"provider"`
+	ProviderToken string    `json:"provider_token"`
+	CustomerID    string    `json:"customer_id,omitempty"`
+	Type          string    `json:"type,omitempty"`
+	Fingerprint   string    `json:"fingerprint,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Store persists vault tokens
+type Store interface {
+	CreateVaultToken(ctx context.Context, token *Token) (*Token, error)
+	GetVaultToken(ctx context.Context, id string) (*Token, error)
+	GetVaultTokenByProviderToken(ctx context.Context, provider, providerToken string) (*Token, error)
+	ListVaultTokensByCustomer(ctx context.Context, customerID string) ([]*Token, error)
+	RemapVaultToken(ctx context.Context, id, provider, providerToken string) (*Token, error)
+	UpdateVaultTokenFingerprint(ctx context.Context, provider, providerToken, fingerprint string) error
+	DeleteVaultToken(ctx context.Context, id string) error
+}
+
+// IsToken reports whether an identifier is a vault token rather than a provider token
+func IsToken(id string) bool {
+	return strings.HasPrefix(id, TokenPrefix)
+}
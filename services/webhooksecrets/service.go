@@ -0,0 +1,387 @@
+package webhooksecrets
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"apis/payments/services/tenantcredentials"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// cipherScope binds encrypted secrets to their use, as tenant credentials
+// are bound to their tenant and provider
+const cipherScope = "stripe_webhook"
+
+// Service rotates the webhook signing secret. A rotation creates a copy of
+// the provider endpoint, which signs deliveries with a new secret, and
+// accepts both secrets until the grace period has passed and deliveries with
+// the new secret have succeeded. The old endpoint is then deleted.
+type Service struct {
+	store     Store
+	endpoints Endpoints
+	cipher    *tenantcredentials.Cipher
+	config    *Config
+	tracer    trace.Tracer
+
+	mu            sync.RWMutex
+	endpointID    string    // Endpoint signing with secret
+	secret        string    // Secret of the current endpoint
+	pending       *Rotation // Rotation in progress, if any
+	pendingSecret string    // New secret of the rotation in progress
+}
+
+// NewService creates a new webhook secret rotation service. Without a
+// cipher, the configured secret is used and rotations cannot be started.
+func NewService(store Store, endpoints Endpoints, cipher *tenantcredentials.Cipher, config *Config) *Service {
+	return &Service{
+		store:      store,
+		endpoints:  endpoints,
+		cipher:     cipher,
+		config:     config,
+		tracer:     otel.Tracer("payments.webhooksecrets"),
+		endpointID: config.EndpointID,
+		secret:     config.Secret,
+	}
+}
+
+// WebhookSecrets returns the secrets webhook signatures are accepted with
+func (s *Service) WebhookSecrets() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.pending != nil {
+		return []string{s.secret, s.pendingSecret}
+	}
+	return []string{s.secret}
+}
+
+// Refresh loads the current secret and any rotation in progress, picking up
+// rotations started or finished by other instances. The secret of the last
+// completed rotation takes precedence over the configured one.
+func (s *Service) Refresh(ctx context.Context) error {
+	ctx, span := s.tracer.Start(ctx, "Refresh")
+	defer span.End()
+
+	endpointID, secret := s.config.EndpointID, s.config.Secret
+	completed, err := s.store.GetLatestCompletedWebhookSecretRotation(ctx)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if err == nil {
+		if secret, err = s.open(completed); err != nil {
+			return err
+		}
+		endpointID = completed.NewEndpointID
+	}
+
+	pending, err := s.store.GetPendingWebhookSecretRotation(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		pending = nil
+	} else if err != nil {
+		return err
+	}
+	var pendingSecret string
+	if pending != nil {
+		if pendingSecret, err = s.open(pending); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.endpointID, s.secret = endpointID, secret
+	s.pending, s.pendingSecret = pending, pendingSecret
+
+	return nil
+}
+
+// Rotate starts a rotation: it creates an endpoint with a new secret and
+// accepts deliveries signed with either secret from then on
+func (s *Service) Rotate(ctx context.Context) (*Rotation, error) {
+	ctx, span := s.tracer.Start(ctx, "Rotate")
+	defer span.End()
+
+	if s.cipher == nil {
+		return nil, tenantcredentials.ErrEncryptionNotConfigured
+	}
+
+	s.mu.RLock()
+	endpointID, inProgress := s.endpointID, s.pending != nil
+	s.mu.RUnlock()
+	if inProgress {
+		return nil, ErrRotationInProgress
+	}
+	if endpointID == "" {
+		return nil, ErrEndpointNotConfigured
+	}
+
+	endpoint, err := s.endpoints.CloneWebhookEndpoint(ctx, endpointID)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := s.cipher.Seal("", cipherScope, []byte(endpoint.Secret))
+	if err != nil {
+		s.discard(ctx, endpoint.ID)
+		return nil, err
+	}
+
+	rotation, err := s.store.CreateWebhookSecretRotation(ctx, &Rotation{
+		ID:            "whsr_" + uuid.New().String(),
+		OldEndpointID: endpointID,
+		NewEndpointID: endpoint.ID,
+		Ciphertext:    ciphertext,
+		GraceEndsAt:   time.Now().UTC().Add(s.config.GracePeriod),
+	})
+	if err != nil {
+		// Most likely another instance started a rotation at the same time
+		s.discard(ctx, endpoint.ID)
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.pending, s.pendingSecret = rotation, endpoint.Secret
+	s.mu.Unlock()
+
+	log.Printf("Started webhook secret rotation %s from endpoint %s to %s", rotation.ID, endpointID, endpoint.ID)
+	return rotation, nil
+}
+
+// Observe records a successfully processed delivery verified with secret,
+// confirming the rotation in progress when it is the new secret
+func (s *Service) Observe(ctx context.Context, secret string) error {
+	s.mu.RLock()
+	pending, pendingSecret := s.pending, s.pendingSecret
+	s.mu.RUnlock()
+	if pending == nil || secret != pendingSecret {
+		return nil
+	}
+
+	ctx, span := s.tracer.Start(ctx, "Observe")
+	defer span.End()
+
+	rotation, err := s.store.RecordWebhookSecretRotationDelivery(ctx, pending.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	if s.pending != nil && s.pending.ID == rotation.ID {
+		s.pending = rotation
+	}
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Advance completes the rotation in progress once its grace period has
+// passed and deliveries with the new secret are confirmed. It returns the
+// completed rotation, or nil if none was completed.
+func (s *Service) Advance(ctx context.Context) (*Rotation, error) {
+	ctx, span := s.tracer.Start(ctx, "Advance")
+	defer span.End()
+
+	s.mu.RLock()
+	pending := s.pending
+	s.mu.RUnlock()
+	if pending == nil {
+		return nil, nil
+	}
+
+	rotation, secret, err := s.load(ctx, pending.ID)
+	if errors.Is(err, ErrNotPending) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().Before(rotation.GraceEndsAt) || !rotation.Confirmed(s.config.MinDeliveries) {
+		return nil, nil
+	}
+
+	return s.complete(ctx, rotation, secret)
+}
+
+// Complete retires the old endpoint of a rotation without waiting for the
+// grace period to end. Deliveries with the new secret must be confirmed.
+func (s *Service) Complete(ctx context.Context, id string) (*Rotation, error) {
+	ctx, span := s.tracer.Start(ctx, "Complete")
+	defer span.End()
+
+	rotation, secret, err := s.load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !rotation.Confirmed(s.config.MinDeliveries) {
+		return nil, ErrNotConfirmed
+	}
+
+	return s.complete(ctx, rotation, secret)
+}
+
+// Cancel abandons a rotation, deleting the endpoint it created and keeping
+// the old secret
+func (s *Service) Cancel(ctx context.Context, id string) (*Rotation, error) {
+	ctx, span := s.tracer.Start(ctx, "Cancel")
+	defer span.End()
+
+	rotation, _, err := s.load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.endpoints.DeleteWebhookEndpoint(ctx, rotation.NewEndpointID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	rotation.Status = StatusCancelled
+	rotation.CancelledAt = &now
+	cancelled, err := s.finish(ctx, rotation)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if s.pending != nil && s.pending.ID == id {
+		s.pending, s.pendingSecret = nil, ""
+	}
+	s.mu.Unlock()
+
+	log.Printf("Cancelled webhook secret rotation %s", id)
+	return cancelled, nil
+}
+
+// Get returns a rotation
+func (s *Service) Get(ctx context.Context, id string) (*Rotation, error) {
+	ctx, span := s.tracer.Start(ctx, "Get")
+	defer span.End()
+
+	return s.store.GetWebhookSecretRotation(ctx, id)
+}
+
+// List returns the most recent rotations
+func (s *Service) List(ctx context.Context, limit int) ([]*Rotation, error) {
+	ctx, span := s.tracer.Start(ctx, "List")
+	defer span.End()
+
+	if limit <= 0 || limit > 100 {
+		limit = 100
+	}
+	return s.store.ListWebhookSecretRotations(ctx, limit)
+}
+
+// Start reloads rotation state and advances the rotation in progress on the
+// configured interval until the returned stop function is called
+func (s *Service) Start() (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(s.config.CheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.Refresh(context.Background()); err != nil {
+					log.Printf("Failed to refresh webhook secrets: %v", err)
+					continue
+				}
+				if _, err := s.Advance(context.Background()); err != nil {
+					log.Printf("Failed to advance webhook secret rotation: %v", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// load returns a pending rotation along with its decrypted new secret
+func (s *Service) load(ctx context.Context, id string) (*Rotation, string, error) {
+	rotation, err := s.store.GetWebhookSecretRotation(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+	if rotation.Status != StatusPending {
+		return nil, "", ErrNotPending
+	}
+
+	secret, err := s.open(rotation)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return rotation, secret, nil
+}
+
+// complete deletes a rotation's old endpoint and switches to its new secret
+func (s *Service) complete(ctx context.Context, rotation *Rotation, secret string) (*Rotation, error) {
+	if err := s.endpoints.DeleteWebhookEndpoint(ctx, rotation.OldEndpointID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	rotation.Status = StatusCompleted
+	rotation.CompletedAt = &now
+	completed, err := s.finish(ctx, rotation)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.endpointID, s.secret = rotation.NewEndpointID, secret
+	s.pending, s.pendingSecret = nil, ""
+	s.mu.Unlock()
+
+	log.Printf("Completed webhook secret rotation %s; endpoint %s retired", rotation.ID, rotation.OldEndpointID)
+	return completed, nil
+}
+
+// finish records a rotation's final status, unless another instance
+// finished it first
+func (s *Service) finish(ctx context.Context, rotation *Rotation) (*Rotation, error) {
+	finished, err := s.store.FinishWebhookSecretRotation(ctx, rotation)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotPending
+	}
+	return finished, err
+}
+
+// open decrypts a rotation's new secret
+func (s *Service) open(rotation *Rotation) (string, error) {
+	if s.cipher == nil {
+		return "", tenantcredentials.ErrEncryptionNotConfigured
+	}
+
+	secret, err := s.cipher.Open("", cipherScope, rotation.Ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret of rotation %s: %w", rotation.ID, err)
+	}
+	return string(secret), nil
+}
+
+// discard deletes an endpoint created for a rotation that could not be started
+func (s *Service) discard(ctx context.Context, endpointID string) {
+	if err := s.endpoints.DeleteWebhookEndpoint(ctx, endpointID); err != nil {
+		log.Printf("Failed to delete unused webhook endpoint %s: %v", endpointID, err)
+	}
+}
@@ -0,0 +1,104 @@
+package webhooksecrets
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"time"
+
+	"apis/payments/services/stripe"
+)
+
+// Rotation statuses
+const (
+	StatusPending   = "pending"   // Both secrets are accepted until the old endpoint is retired
+	StatusCompleted = "completed" // The old endpoint was retired and only the new secret is accepted
+	StatusCancelled = "cancelled" // The new endpoint was deleted and the old secret kept
+)
+
+// ErrRotationInProgress is returned when starting a rotation while another is pending
+var ErrRotationInProgress = errors.New("a webhook secret rotation is already in progress")
+
+// ErrNotPending is returned when completing or cancelling a rotation that has finished
+var ErrNotPending = errors.New("webhook secret rotation is not in progress")
+
+// ErrNotConfirmed is returned when retiring the old secret before enough
+// deliveries signed with the new secret have succeeded
+var ErrNotConfirmed = errors.New("deliveries signed with the new webhook secret are not confirmed yet")
+
+// ErrEndpointNotConfigured is returned when no webhook endpoint to rotate is configured
+var ErrEndpointNotConfigured = errors.New("webhook endpoint ID is not configured")
+
+// Rotation replaces the provider endpoint webhooks are delivered to with a
+// copy signing deliveries with a new secret
+type Rotation struct {
+	ID            string     `json:"id"`
+	Status        string     `json:"status"`
+	OldEndpointID string     `json:"old_endpoint_id"`
+	NewEndpointID string     `json:"new_endpoint_id"`
+	Ciphertext    []byte     `json:"-"`                      // The encrypted new secret
+	Deliveries    int        `json:"deliveries"`             // Deliveries signed with the new secret that succeeded
+	ConfirmedAt   *time.Time `json:"confirmed_at,omitempty"` // First such delivery
+	GraceEndsAt   time.Time  `json:"grace_ends_at"`          // Both secrets are accepted at least until then
+	CompletedAt   *time.Time `json:"completed_at,omitempty"`
+	CancelledAt   *time.Time `json:"cancelled_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// Endpoints manages the provider's webhook endpoints
+type Endpoints interface {
+	CloneWebhookEndpoint(ctx context.Context, endpointID string) (*stripe.WebhookEndpoint, error)
+	DeleteWebhookEndpoint(ctx context.Context, endpointID string) error
+}
+
+// Store persists rotations. Getters return sql.ErrNoRows when no rotation
+// matches, and finishing a rotation that is no longer pending does too.
+type Store interface {
+	CreateWebhookSecretRotation(ctx context.Context, rotation *Rotation) (*Rotation, error)
+	GetWebhookSecretRotation(ctx context.Context, id string) (*Rotation, error)
+	GetPendingWebhookSecretRotation(ctx context.Context) (*Rotation, error)
+	GetLatestCompletedWebhookSecretRotation(ctx context.Context) (*Rotation, error)
+	ListWebhookSecretRotations(ctx context.Context, limit int) ([]*Rotation, error)
+	RecordWebhookSecretRotationDelivery(ctx context.Context, id string) (*Rotation, error)
+	FinishWebhookSecretRotation(ctx context.Context, rotation *Rotation) (*Rotation, error)
+}
+
+// Config configures secret rotation
+type Config struct {
+	EndpointID    string        // Endpoint the configured secret belongs to
+	Secret        string        // Secret used until a rotation completes
+	GracePeriod   time.Duration // How long both secrets are accepted at least
+	MinDeliveries int           // Deliveries with the new secret required to retire the old one
+	CheckInterval time.Duration // How often rotation state is reloaded and advanced
+}
+
+// LoadConfig loads the rotation configuration from environment variables
+func LoadConfig() *Config {
+	config := &Config{
+		EndpointID:    os.Getenv("STRIPE_WEBHOOK_ENDPOINT_ID"),
+		Secret:        os.Getenv("STRIPE_WEBHOOK_SECRET"),
+		GracePeriod:   24 * time.Hour,
+		MinDeliveries: 3,
+		CheckInterval: time.Minute,
+	}
+
+	if hours, err := strconv.Atoi(os.Getenv("WEBHOOK_SECRET_ROTATION_GRACE_HOURS")); err == nil && hours >= 0 {
+		config.GracePeriod = time.Duration(hours) * time.Hour
+	}
+	if deliveries, err := strconv.Atoi(os.Getenv("WEBHOOK_SECRET_ROTATION_MIN_DELIVERIES")); err == nil && deliveries > 0 {
+		config.MinDeliveries = deliveries
+	}
+	if seconds, err := strconv.Atoi(os.Getenv("WEBHOOK_SECRET_ROTATION_CHECK_SECONDS")); err == nil && seconds > 0 {
+		config.CheckInterval = time.Duration(seconds) * time.Second
+	}
+
+	return config
+}
+
+// Confirmed reports whether enough deliveries signed with the new secret
+// have succeeded to retire the old one
+func (r *Rotation) Confirmed(minDeliveries int) bool {
+	return r.Deliveries >= minDeliveries
+}
@@ -0,0 +1,348 @@
+package fixtures
+
+import (
+	"encoding/json"
+	"time"
+
+	"apis/payments/services/autorefund"
+	"apis/payments/services/blocklist"
+	"apis/payments/services/budgets"
+	"apis/payments/services/chargestate"
+	"apis/payments/services/customers"
+	"apis/payments/services/ephemeralkeys"
+	"apis/payments/services/history"
+	"apis/payments/services/holds"
+	"apis/payments/services/invoicing"
+	"apis/payments/services/ledger"
+	"apis/payments/services/money"
+	"apis/payments/services/projections"
+	"apis/payments/services/refundguard"
+	"apis/payments/services/stripe"
+	"apis/payments/services/vault"
+)
+
+// Customer builds a provider customer
+func Customer(overrides ...func(*stripe.Customer)) *stripe.Customer {
+	return build(stripe.Customer{
+		ID:          "cus_fixture",
+		Email:       "jane@example.com",
+		Name:        "Jane Doe",
+		Phone:       "+15555550100",
+		Description: "Fixture customer",
+		Metadata:    map[string]string{"tenant_id": "acme"},
+		Created:     Now.Unix(),
+		Updated:     Now.Unix(),
+	}, overrides)
+}
+
+// PaymentMethod builds a card payment method attached to the fixture customer
+func PaymentMethod(overrides ...func(*stripe.PaymentMethod)) *stripe.PaymentMethod {
+	return build(stripe.PaymentMethod{
+		ID:   "pm_fixture",
+		Type: "card",
+		Card: &stripe.Card{
+			Last4:       "4242",
+			Brand:       "visa",
+			ExpMonth:    12,
+			ExpYear:     2030,
+			Fingerprint: "fp_fixture",
+		},
+		Customer: "cus_fixture",
+		Created:  Now.Unix(),
+	}, overrides)
+}
+
+// Charge builds a captured charge for the fixture customer
+func Charge(overrides ...func(*stripe.Charge)) *stripe.Charge {
+	return build(stripe.Charge{
+		ID:              "ch_fixture",
+		Amount:          2000,
+		AmountDecimal:   "20.00",
+		AmountDisplay:   money.Describe(2000, "usd"),
+		Currency:        "usd",
+		Status:          "succeeded",
+		Captured:        true,
+		CustomerID:      "cus_fixture",
+		PaymentMethodID: "pm_fixture",
+		Description:     "Fixture charge",
+		Metadata:        map[string]string{"tenant_id": "acme"},
+		RiskLevel:       "normal",
+		RiskScore:       12,
+		CardNetwork:     "visa",
+		CredentialType:  "pan",
+		Created:         Now.Unix(),
+	}, overrides)
+}
+
+// Refund builds a partial refund of the fixture charge
+func Refund(overrides ...func(*stripe.Refund)) *stripe.Refund {
+	return build(stripe.Refund{
+		ID:            "re_fixture",
+		ChargeID:      "ch_fixture",
+		Amount:        500,
+		AmountDecimal: "5.00",
+		AmountDisplay: money.Describe(500, "usd"),
+		Currency:      "usd",
+		Status:        "succeeded",
+		Reason:        "requested_by_customer",
+		CreatedAt:     Now,
+		UpdatedAt:     Now,
+	}, overrides)
+}
+
+// Subscription builds an active monthly subscription
+func Subscription(overrides ...func(*stripe.Subscription)) *stripe.Subscription {
+	return build(stripe.Subscription{
+		ID:                 "sub_fixture",
+		CustomerID:         "cus_fixture",
+		PriceID:            "price_fixture",
+		Status:             "active",
+		CurrentPeriodStart: Now.Unix(),
+		CurrentPeriodEnd:   Now.AddDate(0, 1, 0).Unix(),
+		CollectionMethod:   "charge_automatically",
+		Metadata:           map[string]string{"tenant_id": "acme"},
+		Created:            Now.Unix(),
+	}, overrides)
+}
+
+// Invoice builds an open provider invoice sent with NET 30 terms
+func Invoice(overrides ...func(*stripe.Invoice)) *stripe.Invoice {
+	dueDate := Now.AddDate(0, 0, 30)
+	return build(stripe.Invoice{
+		ID:               "in_fixture",
+		Number:           "FIX-0001",
+		CustomerID:       "cus_fixture",
+		SubscriptionID:   "sub_fixture",
+		Status:           "open",
+		CollectionMethod: "send_invoice",
+		AmountDue:        2000,
+		AmountRemaining:  2000,
+		Currency:         "usd",
+		DueDate:          &dueDate,
+		Created:          Now.Unix(),
+	}, overrides)
+}
+
+// Dispute builds a fraud dispute of the fixture charge awaiting a response
+func Dispute(overrides ...func(*stripe.Dispute)) *stripe.Dispute {
+	dueBy := Now.AddDate(0, 0, 21)
+	return build(stripe.Dispute{
+		ID:                "dp_fixture",
+		ChargeID:          "ch_fixture",
+		PaymentIntentID:   "pi_fixture",
+		Amount:            2000,
+		AmountDisplay:     money.Describe(2000, "usd"),
+		Currency:          "usd",
+		Reason:            "fraudulent",
+		Status:            "needs_response",
+		NetworkReasonCode: "10.4",
+		Evidence:          &stripe.DisputeEvidence{CustomerEmailAddress: "jane@example.com"},
+		EvidenceDetails:   &stripe.DisputeEvidenceDetails{DueBy: &dueBy, HasEvidence: true},
+		Created:           Now.Unix(),
+	}, overrides)
+}
+
+// CustomerIdentity builds the recorded identity of the fixture customer
+func CustomerIdentity(overrides ...func(*customers.Identity)) *customers.Identity {
+	return build(customers.Identity{
+		CustomerID: "cus_fixture",
+		TenantID:   "acme",
+		Email:      "jane@example.com",
+		Name:       "jane doe",
+		CreatedAt:  Now,
+	}, overrides)
+}
+
+// HoldPolicy builds a tenant hold policy with every protection enabled
+func HoldPolicy(overrides ...func(*holds.Policy)) *holds.Policy {
+	return build(holds.Policy{
+		TenantID:              "acme",
+		PauseOnDispute:        true,
+		PauseOnFraud:          true,
+		BlockChargesOnDispute: true,
+		BlockChargesOnFraud:   true,
+		MinRiskScore:          75,
+		AutoRelease:           true,
+		CreatedAt:             Now,
+		UpdatedAt:             Now,
+	}, overrides)
+}
+
+// Hold builds an active dispute hold on the fixture customer
+func Hold(overrides ...func(*holds.Hold)) *holds.Hold {
+	return build(holds.Hold{
+		ID:                  "hold_fixture",
+		TenantID:            "acme",
+		CustomerID:          "cus_fixture",
+		Reason:              holds.ReasonDispute,
+		SourceID:            "dp_fixture",
+		Status:              holds.StatusActive,
+		BlocksCharges:       true,
+		PausedSubscriptions: []string{"sub_fixture"},
+		CreatedAt:           Now,
+		UpdatedAt:           Now,
+	}, overrides)
+}
+
+// RefundApproval builds a refund held for step-up approval
+func RefundApproval(overrides ...func(*refundguard.Approval)) *refundguard.Approval {
+	return build(refundguard.Approval{
+		ID:       "rfa_fixture",
+		TenantID: "acme",
+		APIKeyID: "key_fixture",
+		Request:  stripe.RefundRequest{ChargeID: "ch_fixture", Amount: 500},
+		Amount:   500,
+		Violations: []refundguard.Violation{{
+			Dimension: "api_key",
+			Key:       "key_fixture",
+			Usage:     refundguard.Usage{Count: 11, Amount: 5500},
+			Threshold: refundguard.Usage{Count: 10, Amount: 5000},
+		}},
+		Status:    refundguard.ApprovalPending,
+		CreatedAt: Now,
+		UpdatedAt: Now,
+	}, overrides)
+}
+
+// Budget builds a hard-stop monthly budget for the fixture tenant
+func Budget(overrides ...func(*budgets.Budget)) *budgets.Budget {
+	return build(budgets.Budget{
+		TenantID:     "acme",
+		MonthlyLimit: 5000000,
+		Thresholds:   append([]int(nil), budgets.DefaultThresholds...),
+		HardStop:     true,
+		UpdatedBy:    "ops_fixture",
+		UpdatedAt:    Now,
+	}, overrides)
+}
+
+// BlocklistEntry builds a blocked email mirrored to the provider
+func BlocklistEntry(overrides ...func(*blocklist.Entry)) *blocklist.Entry {
+	return build(blocklist.Entry{
+		ID:             "blk_fixture",
+		Type:           blocklist.TypeEmail,
+		Value:          "fraud@example.com",
+		Reason:         "Chargeback fraud",
+		Source:         blocklist.SourceAPI,
+		CreatedBy:      "ops_fixture",
+		ProviderItemID: "rsli_fixture",
+		CreatedAt:      Now,
+	}, overrides)
+}
+
+// ChargeTransition builds the fixture charge's capture
+func ChargeTransition(overrides ...func(*chargestate.Transition)) *chargestate.Transition {
+	return build(chargestate.Transition{
+		ID:        "ctr_fixture",
+		ChargeID:  "ch_fixture",
+		Sequence:  2,
+		From:      chargestate.StateCreated,
+		To:        chargestate.StateCaptured,
+		Source:    chargestate.SourceAPI,
+		CreatedAt: Now,
+	}, overrides)
+}
+
+// VaultToken builds a vault token for the fixture payment method
+func VaultToken(overrides ...func(*vault.Token)) *vault.Token {
+	return build(vault.Token{
+		ID:            "pmt_fixture",
+		Provider:      "stripe",
+		ProviderToken: "pm_fixture",
+		CustomerID:    "cus_fixture",
+		Type:          "card",
+		Fingerprint:   "fp_fixture",
+		CreatedAt:     Now,
+		UpdatedAt:     Now,
+	}, overrides)
+}
+
+// EphemeralKey builds an hour-long key to manage the fixture customer's payment methods
+func EphemeralKey(overrides ...func(*ephemeralkeys.Key)) *ephemeralkeys.Key {
+	return build(ephemeralkeys.Key{
+		ID:         "ek_fixture",
+		CustomerID: "cus_fixture",
+		Scopes:     []string{ephemeralkeys.ScopePaymentMethodsRead, ephemeralkeys.ScopePaymentMethodsWrite},
+		ExpiresAt:  Now.Add(time.Hour),
+		CreatedAt:  Now,
+	}, overrides)
+}
+
+// HistoryVersion builds the first recorded version of the fixture charge
+func HistoryVersion(overrides ...func(*history.Version)) *history.Version {
+	return build(history.Version{
+		ID:         1,
+		Version:    1,
+		EntityType: history.EntityCharge,
+		EntityID:   "ch_fixture",
+		Status:     "succeeded",
+		State:      json.RawMessage(`{"id":"ch_fixture","status":"succeeded"}`),
+		EventID:    "evt_fixture",
+		EventType:  "charge.succeeded",
+		ValidFrom:  Now,
+		RecordedAt: Now,
+	}, overrides)
+}
+
+// AutoRefund builds a succeeded refund of unclaimed cash balance funds
+func AutoRefund(overrides ...func(*autorefund.Refund)) *autorefund.Refund {
+	return build(autorefund.Refund{
+		ID:         "ar_fixture",
+		CustomerID: "cus_fixture",
+		Currency:   "usd",
+		Amount:     1500,
+		RefundID:   "re_fixture",
+		Status:     autorefund.StatusSucceeded,
+		FundedAt:   Now.AddDate(0, 0, -30),
+		CreatedAt:  Now,
+	}, overrides)
+}
+
+// TrackedInvoice builds an invoice tracked until paid
+func TrackedInvoice(overrides ...func(*invoicing.Invoice)) *invoicing.Invoice {
+	return build(invoicing.Invoice{
+		InvoiceID:       "in_fixture",
+		CustomerID:      "cus_fixture",
+		SubscriptionID:  "sub_fixture",
+		Number:          "FIX-0001",
+		AmountDue:       2000,
+		AmountRemaining: 2000,
+		Currency:        "usd",
+		DueDate:         Now.AddDate(0, 0, 30),
+		Status:          invoicing.StatusOpen,
+		CreatedAt:       Now,
+		UpdatedAt:       Now,
+	}, overrides)
+}
+
+// LedgerEntry builds a ledger entry moving refunded unclaimed funds
+func LedgerEntry(overrides ...func(*ledger.Entry)) *ledger.Entry {
+	return build(ledger.Entry{
+		ID:            "le_fixture",
+		DebitAccount:  ledger.AccountUnclaimedFunds,
+		CreditAccount: ledger.AccountProviderBalance,
+		Amount:        1500,
+		Currency:      "usd",
+		ReferenceType: "auto_refund",
+		ReferenceID:   "ar_fixture",
+		CreatedAt:     Now,
+	}, overrides)
+}
+
+// ChargeRow builds the dashboard read model row of the fixture charge
+func ChargeRow(overrides ...func(*projections.ChargeRow)) *projections.ChargeRow {
+	return build(projections.ChargeRow{
+		ChargeID:      "ch_fixture",
+		TenantID:      "acme",
+		CustomerID:    "cus_fixture",
+		CustomerEmail: "jane@example.com",
+		CustomerName:  "Jane Doe",
+		PlanName:      "Pro Monthly",
+		Amount:        2000,
+		Currency:      "usd",
+		Status:        "succeeded",
+		Description:   "Fixture charge",
+		ChargeCreated: Now,
+		UpdatedAt:     Now,
+	}, overrides)
+}
@@ -0,0 +1,42 @@
+// Package fixtures provides builders for domain types, canned provider
+// objects served by a fake Stripe backend, and golden-file assertions for
+// API response contracts.
+//
+// Builders return a fully populated value that overrides adjust:
+//
+//	charge := fixtures.Charge(func(c *stripe.Charge) { c.Status = "failed" })
+//
+// Every fixture is stamped with Now, so output is stable across runs.
+package fixtures
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// Now is the time every fixture was created at
+var Now = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// build applies overrides to a fixture
+func build[T any](fixture T, overrides []func(*T)) *T {
+	for _, override := range overrides {
+		override(&fixture)
+	}
+	return &fixture
+}
+
+// testdata reads a file from this package's testdata directory
+func testdata(t testing.TB, name string) []byte {
+	t.Helper()
+
+	_, file, _, _ := runtime.Caller(0)
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(file), "testdata", name))
+	if err != nil {
+		t.Fatalf("failed to read fixture %s: %v", name, err)
+	}
+
+	return data
+}
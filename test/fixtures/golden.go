@@ -0,0 +1,106 @@
+package fixtures
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// update rewrites golden files with the current output instead of comparing
+var update = flag.Bool("update", false, "rewrite golden files")
+
+// Scrubbed replaces the values of scrubbed fields in golden output
+const Scrubbed = "<scrubbed>"
+
+// Canonical renders a value as indented JSON with object keys sorted, so the
+// same contract always produces the same bytes. Fields named in scrub, at any
+// depth, are replaced with Scrubbed for values that change between runs.
+func Canonical(v any, scrub ...string) ([]byte, error) {
+	raw, ok := v.([]byte)
+	if !ok {
+		var err error
+		if raw, err = json.Marshal(v); err != nil {
+			return nil, fmt.Errorf("failed to encode value: %w", err)
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var decoded any
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode value: %w", err)
+	}
+
+	if len(scrub) > 0 {
+		fields := make(map[string]bool, len(scrub))
+		for _, field := range scrub {
+			fields[field] = true
+		}
+		decoded = scrubFields(decoded, fields)
+	}
+
+	// Maps encode with sorted keys
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(decoded); err != nil {
+		return nil, fmt.Errorf("failed to encode value: %w", err)
+	}
+
+	return out.Bytes(), nil
+}
+
+// AssertGolden compares a value's canonical JSON with testdata/golden/<name>.json
+// in the calling test's package. Run tests with -update to accept new output.
+func AssertGolden(t testing.TB, name string, v any, scrub ...string) {
+	t.Helper()
+
+	actual, err := Canonical(v, scrub...)
+	if err != nil {
+		t.Fatalf("golden %s: %v", name, err)
+	}
+
+	path := filepath.Join("testdata", "golden", name+".json")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("golden %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, actual, 0o644); err != nil {
+			t.Fatalf("golden %s: %v", name, err)
+		}
+		return
+	}
+
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("golden %s: %v (run with -update to create it)", name, err)
+	}
+
+	if !bytes.Equal(expected, actual) {
+		t.Errorf("golden %s does not match (run with -update to accept)\n--- expected\n%s\n--- actual\n%s", name, expected, actual)
+	}
+}
+
+// scrubFields replaces the named fields throughout a decoded JSON value
+func scrubFields(v any, fields map[string]bool) any {
+	switch value := v.(type) {
+	case map[string]any:
+		for key, field := range value {
+			if fields[key] {
+				value[key] = Scrubbed
+			} else {
+				value[key] = scrubFields(field, fields)
+			}
+		}
+	case []any:
+		for i, item := range value {
+			value[i] = scrubFields(item, fields)
+		}
+	}
+	return v
+}
@@ -0,0 +1,135 @@
+package fixtures
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	stripego "github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/form"
+)
+
+// StripeBackend is a fake Stripe API that answers requests with registered
+// fixture objects. Requests without a response fail with resource_missing.
+type StripeBackend struct {
+	mu        sync.Mutex
+	responses map[string]json.RawMessage
+	requests  []string
+}
+
+// NewStripeBackend installs a fake Stripe API for the duration of a test
+func NewStripeBackend(t testing.TB) *StripeBackend {
+	backend := &StripeBackend{responses: make(map[string]json.RawMessage)}
+
+	previous := stripego.GetBackend(stripego.APIBackend)
+	stripego.SetBackend(stripego.APIBackend, backend)
+	t.Cleanup(func() {
+		stripego.SetBackend(stripego.APIBackend, previous)
+	})
+
+	return backend
+}
+
+// StripeObject loads a canned Stripe API object from testdata/stripe/<name>.json,
+// replacing its top-level fields with any overrides
+func StripeObject(t testing.TB, name string, overrides map[string]any) json.RawMessage {
+	t.Helper()
+
+	var object map[string]any
+	if err := json.Unmarshal(testdata(t, "stripe/"+name+".json"), &object); err != nil {
+		t.Fatalf("failed to parse Stripe fixture %s: %v", name, err)
+	}
+	for key, value := range overrides {
+		object[key] = value
+	}
+
+	raw, err := json.Marshal(object)
+	if err != nil {
+		t.Fatalf("failed to encode Stripe fixture %s: %v", name, err)
+	}
+
+	return raw
+}
+
+// Respond answers requests to method and path, e.g. "GET", "/v1/charges/ch_1",
+// with an object
+func (b *StripeBackend) Respond(method, path string, object json.RawMessage) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.responses[method+" "+path] = object
+}
+
+// RespondList answers requests to a list endpoint with a single page of objects
+func (b *StripeBackend) RespondList(path string, objects ...json.RawMessage) {
+	if objects == nil {
+		objects = []json.RawMessage{}
+	}
+
+	page, _ := json.Marshal(map[string]any{
+		"object":   "list",
+		"url":      path,
+		"has_more": false,
+		"data":     objects,
+	})
+	b.Respond(http.MethodGet, path, page)
+}
+
+// Requests returns the method and path of every request made, in order
+func (b *StripeBackend) Requests() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]string(nil), b.requests...)
+}
+
+// Call answers a request with its registered response
+func (b *StripeBackend) Call(method, path, key string, params stripego.ParamsContainer, v stripego.LastResponseSetter) error {
+	return b.respond(method, path, v)
+}
+
+// CallRaw answers a request with its registered response
+func (b *StripeBackend) CallRaw(method, path, key string, body *form.Values, params *stripego.Params, v stripego.LastResponseSetter) error {
+	return b.respond(method, path, v)
+}
+
+// CallStreaming is not supported by the fake
+func (b *StripeBackend) CallStreaming(method, path, key string, params stripego.ParamsContainer, v stripego.StreamingLastResponseSetter) error {
+	return fmt.Errorf("fixtures: streaming %s %s is not supported", method, path)
+}
+
+// CallMultipart is not supported by the fake
+func (b *StripeBackend) CallMultipart(method, path, key, boundary string, body *bytes.Buffer, params *stripego.Params, v stripego.LastResponseSetter) error {
+	return fmt.Errorf("fixtures: multipart %s %s is not supported", method, path)
+}
+
+// SetMaxNetworkRetries is a no-op; the fake never fails transiently
+func (b *StripeBackend) SetMaxNetworkRetries(maxNetworkRetries int64) {}
+
+// respond decodes the registered response for a request into v
+func (b *StripeBackend) respond(method, path string, v stripego.LastResponseSetter) error {
+	b.mu.Lock()
+	key := method + " " + path
+	b.requests = append(b.requests, key)
+	response, ok := b.responses[key]
+	b.mu.Unlock()
+
+	if !ok {
+		return &stripego.Error{
+			Type:           stripego.ErrorTypeInvalidRequest,
+			Code:           stripego.ErrorCodeResourceMissing,
+			HTTPStatusCode: http.StatusNotFound,
+			Msg:            "no fixture for " + key,
+		}
+	}
+
+	if err := json.Unmarshal(response, v); err != nil {
+		return fmt.Errorf("fixtures: failed to decode response for %s: %w", key, err)
+	}
+	v.SetLastResponse(&stripego.APIResponse{StatusCode: http.StatusOK, RawJSON: response})
+
+	return nil
+}
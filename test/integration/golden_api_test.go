@@ -0,0 +1,241 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"apis/payments/services/blocklist"
+	"apis/payments/services/chargestate"
+	"apis/payments/services/stripe"
+	"apis/payments/test/fixtures"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestGoldenAPI tests API response contracts against golden files. Requests
+// are decoded as the handlers decode them and served by a fake Stripe API, and
+// the responses the handlers serialize are compared with testdata/golden.
+// Run with -update to accept intended contract changes.
+func TestGoldenAPI(t *testing.T) {
+	// Provider timestamps are converted with time.Unix
+	local := time.Local
+	time.Local = time.UTC
+	t.Cleanup(func() { time.Local = local })
+
+	backend := fixtures.NewStripeBackend(t)
+	ctx := context.Background()
+
+	decode := func(t *testing.T, body string, request any) {
+		t.Helper()
+		require.NoError(t, json.Unmarshal([]byte(body), request))
+	}
+
+	customers := stripe.NewCustomerService()
+	charges := stripe.NewChargeService()
+	refunds := stripe.NewRefundService()
+	subscriptions := stripe.NewSubscriptionService()
+	disputes := stripe.NewDisputeService()
+	radar := stripe.NewRadarService()
+
+	customer := fixtures.StripeObject(t, "customer", nil)
+	paymentMethod := fixtures.StripeObject(t, "payment_method", nil)
+	charge := fixtures.StripeObject(t, "charge", nil)
+	refund := fixtures.StripeObject(t, "refund", nil)
+	valueList := fixtures.StripeObject(t, "radar_value_list", nil)
+	valueListItem := fixtures.StripeObject(t, "radar_value_list_item", nil)
+
+	t.Run("POST /api/v1/customers", func(t *testing.T) {
+		backend.Respond("POST", "/v1/customers", customer)
+
+		var request stripe.CustomerRequest
+		decode(t, `{"email": "jane@example.com", "name": "Jane Doe", "metadata": {"tenant_id": "acme"}}`, &request)
+
+		created, err := customers.CreateCustomer(ctx, &request)
+		require.NoError(t, err)
+		fixtures.AssertGolden(t, "create_customer", created)
+	})
+
+	t.Run("GET /api/v1/customers/:id", func(t *testing.T) {
+		backend.Respond("GET", "/v1/customers/cus_fixture", customer)
+
+		found, err := customers.GetCustomer(ctx, "cus_fixture")
+		require.NoError(t, err)
+		fixtures.AssertGolden(t, "get_customer", found)
+	})
+
+	t.Run("PUT /api/v1/customers/:id", func(t *testing.T) {
+		backend.Respond("POST", "/v1/customers/cus_fixture", fixtures.StripeObject(t, "customer", map[string]any{"name": "Jane Smith"}))
+
+		var request stripe.CustomerRequest
+		decode(t, `{"email": "jane@example.com", "name": "Jane Smith"}`, &request)
+
+		updated, err := customers.UpdateCustomer(ctx, "cus_fixture", &request)
+		require.NoError(t, err)
+		fixtures.AssertGolden(t, "update_customer", updated, "updated")
+	})
+
+	t.Run("POST /api/v1/payment-methods", func(t *testing.T) {
+		backend.Respond("POST", "/v1/payment_methods", fixtures.StripeObject(t, "payment_method", map[string]any{"customer": nil}))
+		backend.Respond("POST", "/v1/payment_methods/pm_fixture/attach", paymentMethod)
+
+		var request stripe.PaymentMethodRequest
+		decode(t, `{"type": "card", "card": {"token": "tok_visa"}, "customer": "cus_fixture"}`, &request)
+
+		added, err := customers.AddPaymentMethod(ctx, &request)
+		require.NoError(t, err)
+		fixtures.AssertGolden(t, "add_payment_method", added)
+	})
+
+	t.Run("GET /api/v1/payment-methods/:id", func(t *testing.T) {
+		backend.Respond("GET", "/v1/payment_methods/pm_fixture", paymentMethod)
+
+		found, err := customers.GetPaymentMethod(ctx, "pm_fixture")
+		require.NoError(t, err)
+		fixtures.AssertGolden(t, "get_payment_method", found)
+	})
+
+	t.Run("GET /api/v1/customers/:id/payment-methods", func(t *testing.T) {
+		backend.RespondList("/v1/payment_methods", paymentMethod)
+
+		listed, err := customers.ListPaymentMethods(ctx, "cus_fixture", stripe.Page{Limit: 10})
+		require.NoError(t, err)
+		fixtures.AssertGolden(t, "list_payment_methods", listed)
+	})
+
+	t.Run("POST /api/v1/charges", func(t *testing.T) {
+		var expanded map[string]any
+		require.NoError(t, json.Unmarshal(charge, &expanded))
+		backend.Respond("POST", "/v1/payment_intents", fixtures.StripeObject(t, "payment_intent", map[string]any{"latest_charge": expanded}))
+
+		var request stripe.ChargeRequest
+		decode(t, `{"amount_decimal": "20.00", "currency": "usd", "customer_id": "cus_fixture", "payment_method": "pm_fixture", "description": "Fixture charge"}`, &request)
+
+		created, err := charges.CreateCharge(ctx, &request)
+		require.NoError(t, err)
+		fixtures.AssertGolden(t, "create_charge", created)
+	})
+
+	t.Run("GET /api/v1/charges/:id", func(t *testing.T) {
+		backend.Respond("GET", "/v1/charges/ch_fixture", charge)
+
+		found, err := charges.GetCharge(ctx, "ch_fixture")
+		require.NoError(t, err)
+		fixtures.AssertGolden(t, "get_charge", found)
+	})
+
+	t.Run("GET /api/v1/customers/:id/charges", func(t *testing.T) {
+		backend.RespondList("/v1/charges", charge)
+
+		listed, err := charges.ListCharges(ctx, "cus_fixture", stripe.Page{Limit: 10})
+		require.NoError(t, err)
+		fixtures.AssertGolden(t, "list_charges", listed)
+	})
+
+	t.Run("GET /api/v1/charges/:id/transitions", func(t *testing.T) {
+		transitions := []*chargestate.Transition{
+			fixtures.ChargeTransition(func(tr *chargestate.Transition) {
+				tr.ID, tr.Sequence, tr.From, tr.To = "ctr_1", 1, "", chargestate.StateCreated
+			}),
+			fixtures.ChargeTransition(func(tr *chargestate.Transition) {
+				tr.ID, tr.Sequence, tr.From, tr.To = "ctr_2", 2, chargestate.StateCreated, chargestate.StateCaptured
+			}),
+		}
+		fixtures.AssertGolden(t, "list_charge_transitions", transitions)
+	})
+
+	t.Run("POST /api/v1/refunds", func(t *testing.T) {
+		backend.Respond("POST", "/v1/refunds", refund)
+
+		var request stripe.RefundRequest
+		decode(t, `{"charge_id": "ch_fixture", "amount": 500, "reason": "requested_by_customer"}`, &request)
+
+		created, err := refunds.CreateRefund(ctx, &request)
+		require.NoError(t, err)
+		fixtures.AssertGolden(t, "create_refund", created)
+	})
+
+	t.Run("GET /api/v1/refunds/:id", func(t *testing.T) {
+		backend.Respond("GET", "/v1/refunds/re_fixture", refund)
+
+		found, err := refunds.GetRefund(ctx, "re_fixture")
+		require.NoError(t, err)
+		fixtures.AssertGolden(t, "get_refund", found)
+	})
+
+	t.Run("GET /api/v1/charges/:id/refunds", func(t *testing.T) {
+		backend.RespondList("/v1/refunds", refund)
+
+		listed, err := refunds.ListRefunds(ctx, "ch_fixture", stripe.Page{Limit: 10})
+		require.NoError(t, err)
+		fixtures.AssertGolden(t, "list_refunds", listed)
+	})
+
+	t.Run("GET /api/v1/subscriptions/:id", func(t *testing.T) {
+		backend.Respond("GET", "/v1/subscriptions/sub_fixture", fixtures.StripeObject(t, "subscription", nil))
+
+		found, err := subscriptions.GetSubscription(ctx, "sub_fixture")
+		require.NoError(t, err)
+		fixtures.AssertGolden(t, "get_subscription", found)
+	})
+
+	t.Run("GET /api/v1/disputes/:id", func(t *testing.T) {
+		backend.Respond("GET", "/v1/disputes/dp_fixture", fixtures.StripeObject(t, "dispute", nil))
+
+		found, err := disputes.GetDispute(ctx, "dp_fixture")
+		require.NoError(t, err)
+		fixtures.AssertGolden(t, "get_dispute", found)
+	})
+
+	t.Run("GET /api/v1/blocklist", func(t *testing.T) {
+		entries := []*blocklist.Entry{
+			fixtures.BlocklistEntry(),
+			fixtures.BlocklistEntry(func(e *blocklist.Entry) {
+				e.ID, e.Type, e.Value, e.ProviderItemID = "blk_2", blocklist.TypeCardFingerprint, "fp_fixture", ""
+			}),
+		}
+		fixtures.AssertGolden(t, "list_blocklist_entries", entries)
+	})
+
+	t.Run("GET /api/v1/radar/value-lists", func(t *testing.T) {
+		backend.RespondList("/v1/radar/value_lists", valueList)
+
+		listed, err := radar.ListValueLists(ctx)
+		require.NoError(t, err)
+		fixtures.AssertGolden(t, "list_value_lists", listed)
+	})
+
+	t.Run("POST /api/v1/radar/value-lists", func(t *testing.T) {
+		backend.Respond("POST", "/v1/radar/value_lists", valueList)
+
+		var request stripe.ValueListRequest
+		decode(t, `{"alias": "blocked_emails", "name": "Blocked email values", "item_type": "email"}`, &request)
+
+		created, err := radar.CreateValueList(ctx, &request)
+		require.NoError(t, err)
+		fixtures.AssertGolden(t, "create_value_list", created)
+	})
+
+	t.Run("GET /api/v1/radar/value-lists/:id", func(t *testing.T) {
+		backend.Respond("GET", "/v1/radar/value_lists/rsl_fixture", valueList)
+		backend.RespondList("/v1/radar/value_list_items", valueListItem)
+
+		found, err := radar.GetValueList(ctx, "rsl_fixture")
+		require.NoError(t, err)
+		fixtures.AssertGolden(t, "get_value_list", found)
+	})
+
+	t.Run("POST /api/v1/radar/value-lists/:id/items", func(t *testing.T) {
+		backend.Respond("POST", "/v1/radar/value_list_items", valueListItem)
+
+		var request struct {
+			Value string `json:"value"`
+		}
+		decode(t, `{"value": "fraud@example.com"}`, &request)
+
+		added, err := radar.AddValueListItem(ctx, "rsl_fixture", request.Value)
+		require.NoError(t, err)
+		fixtures.AssertGolden(t, "add_value_list_item", added)
+	})
+}
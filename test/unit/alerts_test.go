@@ -0,0 +1,75 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"apis/payments/services/alerts"
+	"apis/payments/services/budgets"
+	"apis/payments/services/refundguard"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAlerts tests delivering typed alerts to the log and to webhooks
+func TestAlerts(t *testing.T) {
+	t.Run("should post the alert as JSON", func(t *testing.T) {
+		var received budgets.Alert
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		}))
+		defer server.Close()
+
+		alerter := alerts.NewWebhookAlerter[*budgets.Alert](server.URL)
+		err := alerter.Alert(context.Background(), &budgets.Alert{TenantID: "acme", Threshold: 80, Spent: 4000, MonthlyLimit: 5000})
+
+		require.NoError(t, err)
+		assert.Equal(t, "acme", received.TenantID)
+		assert.Equal(t, 80, received.Threshold)
+		assert.Equal(t, int64(4000), received.Spent)
+	})
+
+	t.Run("should fail when the endpoint rejects the alert", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		alerter := alerts.NewWebhookAlerter[*refundguard.Alert](server.URL)
+		err := alerter.Alert(context.Background(), &refundguard.Alert{TenantID: "acme"})
+
+		assert.ErrorContains(t, err, "status 503")
+	})
+
+	t.Run("should deliver to every alerter and return the first error", func(t *testing.T) {
+		first := &MockAlerter{err: errors.New("first")}
+		second := &MockAlerter{err: errors.New("second")}
+		alerter := alerts.MultiAlerter[*refundguard.Alert]{first, alerts.LogAlerter[*refundguard.Alert]{}, second}
+
+		err := alerter.Alert(context.Background(), &refundguard.Alert{ApprovalID: "rfap_1"})
+
+		assert.EqualError(t, err, "first")
+		assert.Len(t, first.alerts, 1)
+		assert.Len(t, second.alerts, 1)
+	})
+
+	t.Run("should describe each refund violation", func(t *testing.T) {
+		alert := &refundguard.Alert{
+			ApprovalID: "rfap_1",
+			Violations: []refundguard.Violation{{Dimension: "api_key", Key: "key_1"}, {Dimension: "tenant", Key: "acme"}},
+		}
+
+		messages := alert.Messages()
+
+		require.Len(t, messages, 2)
+		assert.Contains(t, messages[0], "api_key key_1")
+		assert.Contains(t, messages[1], "held as rfap_1")
+	})
+}
@@ -0,0 +1,176 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"apis/payments/services/analytics"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAnalyticsReports tests breaking charge, revenue and subscription
+// metrics into buckets per currency
+func TestAnalyticsReports(t *testing.T) {
+	ctx := context.Background()
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC) }
+
+	t.Run("should fill every bucket of each currency's series", func(t *testing.T) {
+		store := &MockAnalyticsStore{charges: []*analytics.ChargeBucket{
+			{Start: day(2), Currency: "usd", Charges: 4, Succeeded: 3, Failed: 1, Volume: 3000},
+			{Start: day(3), Currency: "eur", Charges: 2, Succeeded: 2, Volume: 500},
+		}}
+		query := &analytics.Query{TenantID: "acme", From: day(2).Add(10 * time.Hour), To: day(5)}
+
+		series, err := analytics.NewService(store).Charges(ctx, query)
+		require.NoError(t, err)
+		assert.Equal(t, day(2), store.query.From, "the range starts with the first bucket")
+		assert.Equal(t, analytics.GranularityDay, store.query.Granularity)
+
+		require.Len(t, series, 2)
+		assert.Equal(t, "eur", series[0].Currency)
+		assert.Equal(t, "usd", series[1].Currency)
+		usd := series[1].Buckets
+		require.Len(t, usd, 3)
+		assert.Equal(t, day(2), usd[0].Start)
+		assert.Equal(t, 0.75, usd[0].SuccessRate)
+		assert.Equal(t, &analytics.ChargeBucket{Start: day(3), Currency: "usd"}, usd[1])
+		assert.Equal(t, day(4), usd[2].Start)
+	})
+
+	t.Run("should only report the queried currency", func(t *testing.T) {
+		store := &MockAnalyticsStore{}
+		query := &analytics.Query{From: day(2), To: day(4), Currency: "gbp"}
+
+		series, err := analytics.NewService(store).Charges(ctx, query)
+		require.NoError(t, err)
+		require.Len(t, series, 1)
+		assert.Equal(t, "gbp", series[0].Currency)
+		assert.Len(t, series[0].Buckets, 2)
+	})
+
+	t.Run("should start weeks on Monday and months on the first", func(t *testing.T) {
+		assert.Equal(t, day(2), analytics.GranularityWeek.Truncate(day(8).Add(23*time.Hour)))
+		assert.Equal(t, day(9), analytics.GranularityWeek.Truncate(day(9)))
+		assert.Equal(t, day(1), analytics.GranularityMonth.Truncate(day(31)))
+		assert.Equal(t, time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), analytics.GranularityMonth.Next(day(1)))
+	})
+
+	t.Run("should reject invalid queries", func(t *testing.T) {
+		service := analytics.NewService(&MockAnalyticsStore{})
+
+		_, err := service.Charges(ctx, &analytics.Query{From: day(2), To: day(3), Granularity: "hour"})
+		assert.ErrorIs(t, err, analytics.ErrInvalidGranularity)
+		_, err = service.Revenue(ctx, &analytics.Query{From: day(3), To: day(2)})
+		assert.ErrorIs(t, err, analytics.ErrInvalidRange)
+		_, err = service.MRR(ctx, &analytics.Query{From: day(1), To: day(1).AddDate(2, 0, 0)})
+		assert.ErrorIs(t, err, analytics.ErrRangeTooLarge)
+		_, err = service.DeclineReasons(ctx, &analytics.Query{From: day(2), To: day(2)})
+		assert.ErrorIs(t, err, analytics.ErrInvalidRange)
+	})
+
+	t.Run("should net refunds off succeeded charges", func(t *testing.T) {
+		store := &MockAnalyticsStore{
+			charges: []*analytics.ChargeBucket{{Start: day(2), Currency: "usd", Charges: 4, Succeeded: 3, Volume: 3000}},
+			refunds: []*analytics.RefundBucket{
+				{Start: day(2), Currency: "usd", Refunds: 1, Amount: 500},
+				{Start: day(3), Currency: "usd", Refunds: 1, Amount: 200},
+			},
+		}
+
+		series, err := analytics.NewService(store).Revenue(ctx, &analytics.Query{From: day(2), To: day(4)})
+		require.NoError(t, err)
+		require.Len(t, series, 1)
+		assert.Equal(t, []*analytics.RevenueBucket{
+			{Start: day(2), Currency: "usd", Gross: 3000, Refunded: 500, Net: 2500, Charges: 3, Refunds: 1},
+			{Start: day(3), Currency: "usd", Refunded: 200, Net: -200, Refunds: 1},
+		}, series[0].Buckets)
+	})
+
+	subscriptions := func() *MockAnalyticsStore {
+		return &MockAnalyticsStore{changes: []*analytics.SubscriptionChange{
+			{SubscriptionID: "sub_a", Status: "active", Currency: "usd", MRR: 1000, At: day(1)},
+			{SubscriptionID: "sub_b", Status: "active", Currency: "usd", MRR: 500, At: day(2).Add(time.Hour)},
+			{SubscriptionID: "sub_c", Status: "trialing", Currency: "usd", MRR: 800, At: day(2).Add(2 * time.Hour)},
+			{SubscriptionID: "sub_a", Status: "active", Currency: "usd", MRR: 1500, At: day(3).Add(time.Hour)},
+			{SubscriptionID: "sub_a", Status: "canceled", Currency: "usd", MRR: 1500, At: day(4).Add(time.Hour)},
+		}}
+	}
+
+	t.Run("should replay subscription changes into MRR movements", func(t *testing.T) {
+		series, err := analytics.NewService(subscriptions()).MRR(ctx, &analytics.Query{From: day(2), To: day(5)})
+		require.NoError(t, err)
+		require.Len(t, series, 1)
+		assert.Equal(t, []*analytics.MRRBucket{
+			{Start: day(2), Currency: "usd", MRR: 1500, NewMRR: 500, ActiveSubscriptions: 2},
+			{Start: day(3), Currency: "usd", MRR: 2000, ExpansionMRR: 500, ActiveSubscriptions: 2},
+			{Start: day(4), Currency: "usd", MRR: 500, ChurnedMRR: 1500, ActiveSubscriptions: 1},
+		}, series[0].Buckets)
+	})
+
+	t.Run("should measure churn against subscriptions paying at the start", func(t *testing.T) {
+		series, err := analytics.NewService(subscriptions()).Churn(ctx, &analytics.Query{From: day(2), To: day(5)})
+		require.NoError(t, err)
+		require.Len(t, series, 1)
+		assert.Equal(t, []*analytics.ChurnBucket{
+			{Start: day(2), Currency: "usd", ActiveAtStart: 1, MRRAtStart: 1000},
+			{Start: day(3), Currency: "usd", ActiveAtStart: 2, MRRAtStart: 1500},
+			{Start: day(4), Currency: "usd", ActiveAtStart: 2, Churned: 1, ChurnRate: 0.5, MRRAtStart: 2000, ChurnedMRR: 1500, RevenueChurnRate: 0.75},
+		}, series[0].Buckets)
+	})
+
+	t.Run("should rank decline reasons by frequency", func(t *testing.T) {
+		store := &MockAnalyticsStore{declines: []*analytics.DeclineReason{
+			{Code: "card_declined", Count: 2},
+			{Code: "insufficient_funds", Count: 6},
+			{Code: "", Count: 2},
+		}}
+
+		reasons, err := analytics.NewService(store).DeclineReasons(ctx, &analytics.Query{From: day(2), To: day(5)})
+		require.NoError(t, err)
+		assert.Equal(t, []*analytics.DeclineReason{
+			{Code: "insufficient_funds", Count: 6, Share: 0.6},
+			{Code: "", Count: 2, Share: 0.2},
+			{Code: "card_declined", Count: 2, Share: 0.2},
+		}, reasons)
+	})
+
+	t.Run("should normalize recurring prices to a month", func(t *testing.T) {
+		assert.Equal(t, int64(1000), analytics.MonthlyAmount(12000, 1, "year", 1))
+		assert.Equal(t, int64(3000), analytics.MonthlyAmount(4500, 2, "month", 3))
+		assert.Equal(t, int64(433), analytics.MonthlyAmount(100, 1, "week", 1))
+		assert.Equal(t, int64(2000), analytics.MonthlyAmount(1000, 2, "month", 0))
+		assert.Zero(t, analytics.MonthlyAmount(1000, 1, "", 0))
+	})
+}
+
+// MockAnalyticsStore returns fixed aggregates, recording the last query
+type MockAnalyticsStore struct {
+	charges  []*analytics.ChargeBucket
+	refunds  []*analytics.RefundBucket
+	changes  []*analytics.SubscriptionChange
+	declines []*analytics.DeclineReason
+	query    analytics.Query
+}
+
+func (m *MockAnalyticsStore) ChargeBuckets(ctx context.Context, query *analytics.Query) ([]*analytics.ChargeBucket, error) {
+	m.query = *query
+	return m.charges, nil
+}
+
+func (m *MockAnalyticsStore) RefundBuckets(ctx context.Context, query *analytics.Query) ([]*analytics.RefundBucket, error) {
+	m.query = *query
+	return m.refunds, nil
+}
+
+func (m *MockAnalyticsStore) SubscriptionChanges(ctx context.Context, query *analytics.Query) ([]*analytics.SubscriptionChange, error) {
+	m.query = *query
+	return m.changes, nil
+}
+
+func (m *MockAnalyticsStore) DeclineReasons(ctx context.Context, query *analytics.Query) ([]*analytics.DeclineReason, error) {
+	m.query = *query
+	return m.declines, nil
+}
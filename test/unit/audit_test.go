@@ -0,0 +1,207 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"apis/payments/services/audit"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAuditLog tests recording mutations in the hash-chained audit log,
+// diffing resource states and verifying the chain
+func TestAuditLog(t *testing.T) {
+	ctx := context.Background()
+	call := func(method, route string, params map[string]string, response string) *audit.Call {
+		return &audit.Call{
+			Method:    method,
+			Route:     route,
+			Path:      "/api/v1" + route,
+			Params:    params,
+			Status:    http.StatusOK,
+			Response:  []byte(response),
+			TenantID:  "acme",
+			ActorType: audit.ActorAPIKey,
+			ActorID:   "key_1",
+			IP:        "203.0.113.7",
+		}
+	}
+
+	t.Run("should classify calls by their route", func(t *testing.T) {
+		for _, tc := range []struct {
+			method, route, responseID string
+			action, resourceType, id  string
+		}{
+			{http.MethodPost, "/customers", "cus_1", "create", "customers", "cus_1"},
+			{http.MethodPut, "/customers/:id", "cus_1", "update", "customers", "cus_1"},
+			{http.MethodDelete, "/customers/:id", "", "delete", "customers", "cus_1"},
+			{http.MethodPost, "/payment-intents/:id/capture", "cus_1", "capture", "payment-intents", "cus_1"},
+			{http.MethodPost, "/composite-charges/:id/refunds", "re_1", "create", "refunds", "re_1"},
+			{http.MethodPost, "/invoices/:id/mark-paid", "", "mark_paid", "invoices", "cus_1"},
+			{http.MethodDelete, "/subscriptions/:id/scheduled-change", "", "delete_scheduled_change", "subscriptions", "cus_1"},
+			{http.MethodPost, "/refunds/batch", "rb_1", "batch", "refunds", "rb_1"},
+		} {
+			action, resourceType, id := audit.Classify(tc.method, tc.route, map[string]string{"id": "cus_1"}, tc.responseID)
+			assert.Equal(t, []string{tc.action, tc.resourceType, tc.id}, []string{action, resourceType, id}, tc.method+" "+tc.route)
+		}
+		assert.True(t, audit.Recorded(http.MethodPost, http.StatusCreated))
+		assert.False(t, audit.Recorded(http.MethodPost, http.StatusBadRequest))
+		assert.False(t, audit.Recorded(http.MethodGet, http.StatusOK))
+	})
+
+	t.Run("should diff a resource against its last recorded state", func(t *testing.T) {
+		service := audit.NewService(&MockAuditStore{})
+
+		created, err := service.Record(ctx, call(http.MethodPost, "/customers", nil,
+			`{"id":"cus_1","email":"jane@example.com","address":{"city":"Berlin"},"secret":"psk_live"}`))
+		require.NoError(t, err)
+		assert.Equal(t, "create", created.Action)
+		assert.Nil(t, created.Before)
+		assert.NotContains(t, string(created.After), "psk_live", "secrets are redacted")
+
+		updated, err := service.Record(ctx, call(http.MethodPut, "/customers/:id", map[string]string{"id": "cus_1"},
+			`{"id":"cus_1","email":"jane@example.org","address":{"city":"Berlin"}}`))
+		require.NoError(t, err)
+		assert.JSONEq(t, string(created.After), string(updated.Before))
+		require.Len(t, updated.Changes, 1)
+		assert.Equal(t, "email", updated.Changes[0].Field)
+		assert.JSONEq(t, `"jane@example.com"`, string(updated.Changes[0].Before))
+		assert.JSONEq(t, `"jane@example.org"`, string(updated.Changes[0].After))
+
+		deleted, err := service.Record(ctx, call(http.MethodDelete, "/customers/:id", map[string]string{"id": "cus_1"},
+			`{"customer_id":"cus_1","purge_after":"2026-11-15T00:00:00Z"}`))
+		require.NoError(t, err)
+		assert.JSONEq(t, string(updated.After), string(deleted.Before))
+		assert.Nil(t, deleted.After, "deletion receipts aren't the resource's state")
+		assert.Len(t, deleted.Changes, 3, "every field is removed")
+	})
+
+	t.Run("should chain entries and detect tampering", func(t *testing.T) {
+		store := &MockAuditStore{}
+		service := audit.NewService(store)
+		for _, id := range []string{"cus_1", "cus_2", "cus_3"} {
+			_, err := service.Record(ctx, call(http.MethodPost, "/customers", nil, `{"id":"`+id+`"}`))
+			require.NoError(t, err)
+		}
+
+		require.Len(t, store.entries, 3)
+		assert.Equal(t, audit.GenesisHash, store.entries[0].PreviousHash)
+		assert.Equal(t, store.entries[1].Hash, store.entries[2].PreviousHash)
+		assert.Equal(t, int64(3), store.entries[2].Sequence)
+
+		verification, err := service.Verify(ctx)
+		require.NoError(t, err)
+		assert.True(t, verification.Valid)
+		assert.Equal(t, int64(3), verification.Checked)
+
+		store.entries[1].ActorID = "key_2"
+		verification, err = service.Verify(ctx)
+		require.NoError(t, err)
+		assert.False(t, verification.Valid)
+		assert.Equal(t, int64(2), *verification.BrokenAt)
+	})
+
+	t.Run("should chain onto entries appended by other instances", func(t *testing.T) {
+		store := &MockAuditStore{}
+		service := audit.NewService(store)
+		_, err := service.Record(ctx, call(http.MethodPost, "/customers", nil, `{"id":"cus_1"}`))
+		require.NoError(t, err)
+
+		store.onAppend = func() {
+			store.onAppend = nil
+			store.entries = append(store.entries, &audit.Entry{Sequence: 2, Hash: "other"})
+		}
+		entry, err := service.Record(ctx, call(http.MethodPost, "/customers", nil, `{"id":"cus_2"}`))
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), entry.Sequence)
+		assert.Equal(t, "other", entry.PreviousHash)
+	})
+
+	t.Run("should reject empty created ranges and unknown entries", func(t *testing.T) {
+		service := audit.NewService(&MockAuditStore{})
+		now := time.Now()
+
+		_, err := service.List(ctx, audit.Filter{CreatedAfter: &now, CreatedBefore: &now})
+		assert.ErrorIs(t, err, audit.ErrInvalidCreatedRange)
+		_, err = service.Get(ctx, 1)
+		assert.ErrorIs(t, err, audit.ErrEntryNotFound)
+	})
+}
+
+// MockAuditStore keeps audit entries in memory in sequence order, storing
+// changes as JSON as the database does
+type MockAuditStore struct {
+	entries  []*audit.Entry
+	onAppend func()
+}
+
+func (m *MockAuditStore) AppendAuditEntry(ctx context.Context, entry *audit.Entry) (bool, error) {
+	if m.onAppend != nil {
+		m.onAppend()
+	}
+	if len(m.entries) > 0 && m.entries[len(m.entries)-1].Sequence >= entry.Sequence {
+		return false, nil
+	}
+
+	stored := *entry
+	changes, err := json.Marshal(entry.Changes)
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(changes, &stored.Changes); err != nil {
+		return false, err
+	}
+	m.entries = append(m.entries, &stored)
+	return true, nil
+}
+
+func (m *MockAuditStore) GetAuditEntry(ctx context.Context, sequence int64) (*audit.Entry, error) {
+	for _, entry := range m.entries {
+		if entry.Sequence == sequence {
+			return entry, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *MockAuditStore) GetLatestAuditEntry(ctx context.Context) (*audit.Entry, error) {
+	if len(m.entries) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return m.entries[len(m.entries)-1], nil
+}
+
+func (m *MockAuditStore) GetLatestResourceAuditEntry(ctx context.Context, resourceType, resourceID string) (*audit.Entry, error) {
+	for i := len(m.entries) - 1; i >= 0; i-- {
+		if m.entries[i].ResourceType == resourceType && m.entries[i].ResourceID == resourceID {
+			return m.entries[i], nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *MockAuditStore) ListAuditEntries(ctx context.Context, filter audit.Filter) ([]*audit.Entry, error) {
+	var entries []*audit.Entry
+	for i := len(m.entries) - 1; i >= 0 && len(entries) < filter.Limit; i-- {
+		if filter.TenantID == "" || m.entries[i].TenantID == filter.TenantID {
+			entries = append(entries, m.entries[i])
+		}
+	}
+	return entries, nil
+}
+
+func (m *MockAuditStore) ListAuditEntriesFrom(ctx context.Context, sequence int64, limit int) ([]*audit.Entry, error) {
+	var entries []*audit.Entry
+	for _, entry := range m.entries {
+		if entry.Sequence >= sequence && len(entries) < limit {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
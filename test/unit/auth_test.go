@@ -0,0 +1,300 @@
+package test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"apis/payments/services/auth"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAuth tests API key issuing, rotation and revocation, bearer token
+// verification and the scopes each route requires
+func TestAuth(t *testing.T) {
+	ctx := context.Background()
+	config := &auth.Config{Enabled: true, JWTSecret: "test-secret", JWTIssuer: "https://issuer.example", JWTAudience: "payments", RotationGrace: time.Hour}
+
+	setup := func() (*auth.Service, *MockAPIKeyStore) {
+		store := NewMockAPIKeyStore()
+		return auth.NewService(store, config), store
+	}
+
+	sign := func(secret string, header, claims map[string]any) string {
+		encode := func(v any) string {
+			data, _ := json.Marshal(v)
+			return base64.RawURLEncoding.EncodeToString(data)
+		}
+		unsigned := encode(header) + "." + encode(claims)
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(unsigned))
+		return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	}
+
+	validClaims := func() map[string]any {
+		return map[string]any{
+			"sub":       "svc-checkout",
+			"iss":       "https://issuer.example",
+			"aud":       []string{"payments"},
+			"exp":       time.Now().Add(time.Hour).Unix(),
+			"scope":     "read charges:write",
+			"tenant_id": "tenant_1",
+		}
+	}
+	hs256 := map[string]any{"alg": "HS256", "typ": "JWT"}
+
+	t.Run("should create a key and authenticate its secret", func(t *testing.T) {
+		service, store := setup()
+
+		key, err := service.CreateKey(ctx, &auth.CreateKeyRequest{Name: "checkout", Scopes: []string{auth.ScopeRead, auth.ScopeChargesWrite}, TenantID: "tenant_1"})
+		require.NoError(t, err)
+
+		assert.True(t, strings.HasPrefix(key.Secret, auth.SecretPrefix))
+		assert.True(t, strings.HasPrefix(key.Secret, key.SecretHint))
+		assert.Empty(t, store.keys[key.ID].Secret, "secret is not stored")
+		assert.Contains(t, store.byHash, auth.HashSecret(key.Secret))
+
+		principal, err := service.Authenticate(ctx, key.Secret)
+		require.NoError(t, err)
+		assert.Equal(t, auth.PrincipalAPIKey, principal.Type)
+		assert.Equal(t, key.ID, principal.ID)
+		assert.Equal(t, "tenant_1", principal.TenantID)
+		assert.True(t, principal.Allows(auth.ScopeChargesWrite))
+		assert.False(t, principal.Allows(auth.ScopeRefundsWrite))
+	})
+
+	t.Run("should reject keys without a name or with unknown scopes", func(t *testing.T) {
+		service, _ := setup()
+
+		_, err := service.CreateKey(ctx, &auth.CreateKeyRequest{Scopes: []string{auth.ScopeRead}})
+		assert.Error(t, err)
+
+		_, err = service.CreateKey(ctx, &auth.CreateKeyRequest{Name: "ops", Scopes: []string{"charges:delete"}})
+		assert.ErrorIs(t, err, auth.ErrUnknownScope)
+
+		_, err = service.CreateKey(ctx, &auth.CreateKeyRequest{Name: "ops"})
+		assert.Error(t, err)
+	})
+
+	t.Run("should reject unknown and revoked secrets", func(t *testing.T) {
+		service, _ := setup()
+
+		_, err := service.Authenticate(ctx, auth.SecretPrefix+"unknown")
+		assert.ErrorIs(t, err, auth.ErrUnauthenticated)
+
+		_, err = service.Authenticate(ctx, "")
+		assert.ErrorIs(t, err, auth.ErrUnauthenticated)
+
+		key, err := service.CreateKey(ctx, &auth.CreateKeyRequest{Name: "ops", Scopes: []string{auth.ScopeAdmin}})
+		require.NoError(t, err)
+		require.NoError(t, service.RevokeKey(ctx, key.ID))
+
+		_, err = service.Authenticate(ctx, key.Secret)
+		assert.ErrorIs(t, err, auth.ErrUnauthenticated)
+		assert.ErrorIs(t, service.RevokeKey(ctx, key.ID), auth.ErrKeyNotFound)
+	})
+
+	t.Run("should keep a rotated key working for the grace period", func(t *testing.T) {
+		service, store := setup()
+
+		old, err := service.CreateKey(ctx, &auth.CreateKeyRequest{Name: "checkout", Scopes: []string{auth.ScopeRead}, TenantID: "tenant_1"})
+		require.NoError(t, err)
+
+		replacement, err := service.RotateKey(ctx, old.ID, nil, "apikey_admin")
+		require.NoError(t, err)
+
+		assert.NotEqual(t, old.Secret, replacement.Secret)
+		assert.Equal(t, old.ID, replacement.RotatedFrom)
+		assert.Equal(t, old.Scopes, replacement.Scopes)
+		assert.Equal(t, "tenant_1", replacement.TenantID)
+		require.NotNil(t, store.keys[old.ID].ExpiresAt)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), *store.keys[old.ID].ExpiresAt, time.Minute)
+
+		_, err = service.Authenticate(ctx, old.Secret)
+		assert.NoError(t, err, "old key works during the grace period")
+		_, err = service.Authenticate(ctx, replacement.Secret)
+		assert.NoError(t, err)
+	})
+
+	t.Run("should expire a key rotated without grace immediately", func(t *testing.T) {
+		service, _ := setup()
+
+		old, err := service.CreateKey(ctx, &auth.CreateKeyRequest{Name: "checkout", Scopes: []string{auth.ScopeRead}})
+		require.NoError(t, err)
+
+		grace := time.Duration(0)
+		_, err = service.RotateKey(ctx, old.ID, &grace, "admin")
+		require.NoError(t, err)
+
+		_, err = service.Authenticate(ctx, old.Secret)
+		assert.ErrorIs(t, err, auth.ErrUnauthenticated)
+
+		_, err = service.RotateKey(ctx, old.ID, nil, "admin")
+		assert.ErrorIs(t, err, auth.ErrKeyNotFound, "expired keys cannot be rotated")
+
+		_, err = service.RotateKey(ctx, "apikey_missing", nil, "admin")
+		assert.ErrorIs(t, err, auth.ErrKeyNotFound)
+	})
+
+	t.Run("should authenticate a signed bearer token", func(t *testing.T) {
+		service, _ := setup()
+
+		principal, err := service.Authenticate(ctx, sign("test-secret", hs256, validClaims()))
+		require.NoError(t, err)
+
+		assert.Equal(t, auth.PrincipalJWT, principal.Type)
+		assert.Equal(t, "svc-checkout", principal.ID)
+		assert.Equal(t, "tenant_1", principal.TenantID)
+		assert.Equal(t, []string{auth.ScopeRead, auth.ScopeChargesWrite}, principal.Scopes)
+
+		claims := validClaims()
+		delete(claims, "scope")
+		claims["scopes"] = []string{auth.ScopeRefundsWrite}
+		claims["aud"] = "payments"
+		claims["roles"] = []string{"finance"}
+		principal, err = service.Authenticate(ctx, sign("test-secret", hs256, claims))
+		require.NoError(t, err)
+		assert.Equal(t, []string{auth.ScopeRefundsWrite}, principal.Scopes)
+		assert.Equal(t, []string{"finance"}, principal.Roles)
+	})
+
+	t.Run("should reject invalid bearer tokens", func(t *testing.T) {
+		service, _ := setup()
+
+		with := func(key string, value any) map[string]any {
+			claims := validClaims()
+			if value == nil {
+				delete(claims, key)
+			} else {
+				claims[key] = value
+			}
+			return claims
+		}
+
+		tokens := map[string]string{
+			"wrong secret":   sign("other-secret", hs256, validClaims()),
+			"alg none":       sign("test-secret", map[string]any{"alg": "none"}, validClaims()),
+			"expired":        sign("test-secret", hs256, with("exp", time.Now().Add(-time.Hour).Unix())),
+			"no expiry":      sign("test-secret", hs256, with("exp", nil)),
+			"not yet valid":  sign("test-secret", hs256, with("nbf", time.Now().Add(time.Hour).Unix())),
+			"wrong issuer":   sign("test-secret", hs256, with("iss", "https://other.example")),
+			"wrong audience": sign("test-secret", hs256, with("aud", "billing")),
+			"no subject":     sign("test-secret", hs256, with("sub", nil)),
+			"malformed":      "not.a.token",
+		}
+		for name, token := range tokens {
+			_, err := service.Authenticate(ctx, token)
+			assert.ErrorIs(t, err, auth.ErrUnauthenticated, name)
+		}
+
+		withoutSecret := auth.NewService(NewMockAPIKeyStore(), &auth.Config{Enabled: true})
+		_, err := withoutSecret.Authenticate(ctx, sign("", hs256, validClaims()))
+		assert.ErrorIs(t, err, auth.ErrUnauthenticated, "tokens are rejected without a configured secret")
+	})
+
+	t.Run("should require the scope each route needs", func(t *testing.T) {
+		cases := []struct {
+			method, path, scope string
+		}{
+			{"GET", "/charges/ch_1", auth.ScopeRead},
+			{"GET", "/refunds", auth.ScopeRead},
+			{"POST", "/charges", auth.ScopeChargesWrite},
+			{"POST", "/composite-charges", auth.ScopeChargesWrite},
+			{"POST", "/composite-charges/cc_1/refunds", auth.ScopeRefundsWrite},
+			{"POST", "/refunds", auth.ScopeRefundsWrite},
+			{"POST", "/refund-approvals/ra_1/approve", auth.ScopeRefundsWrite},
+			{"PUT", "/customers/cus_1", auth.ScopeWrite},
+			{"POST", "/charges-export", auth.ScopeWrite},
+			{"POST", "/graphql", auth.ScopeRead},
+			{"GET", "/api-keys", auth.ScopeAdmin},
+			{"POST", "/api-keys/apikey_1/rotate", auth.ScopeAdmin},
+		}
+		for _, c := range cases {
+			assert.Equal(t, c.scope, auth.RequiredScope(c.method, c.path), "%s %s", c.method, c.path)
+		}
+
+		admin := &auth.Principal{Scopes: []string{auth.ScopeAdmin}}
+		readOnly := &auth.Principal{Scopes: []string{auth.ScopeRead}}
+		assert.True(t, admin.Allows(auth.ScopeRefundsWrite))
+		assert.True(t, readOnly.Allows(auth.ScopeRead))
+		assert.False(t, readOnly.Allows(auth.ScopeWrite))
+	})
+}
+
+// MockAPIKeyStore is an in-memory auth.Store
+type MockAPIKeyStore struct {
+	keys   map[string]*auth.APIKey
+	byHash map[string]string
+}
+
+// NewMockAPIKeyStore creates an empty MockAPIKeyStore
+func NewMockAPIKeyStore() *MockAPIKeyStore {
+	return &MockAPIKeyStore{
+		keys:   make(map[string]*auth.APIKey),
+		byHash: make(map[string]string),
+	}
+}
+
+func (m *MockAPIKeyStore) CreateAPIKey(ctx context.Context, key *auth.APIKey, secretHash string) (*auth.APIKey, error) {
+	if _, exists := m.byHash[secretHash]; exists {
+		return nil, fmt.Errorf("duplicate secret hash")
+	}
+	stored := *key
+	stored.CreatedAt = time.Now()
+	m.keys[key.ID] = &stored
+	m.byHash[secretHash] = key.ID
+	created := stored
+	return &created, nil
+}
+
+func (m *MockAPIKeyStore) GetAPIKey(ctx context.Context, id string) (*auth.APIKey, error) {
+	key, ok := m.keys[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *key
+	return &copied, nil
+}
+
+func (m *MockAPIKeyStore) GetAPIKeyBySecretHash(ctx context.Context, secretHash string) (*auth.APIKey, error) {
+	id, ok := m.byHash[secretHash]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return m.GetAPIKey(ctx, id)
+}
+
+func (m *MockAPIKeyStore) ListAPIKeys(ctx context.Context) ([]*auth.APIKey, error) {
+	keys := make([]*auth.APIKey, 0, len(m.keys))
+	for _, key := range m.keys {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (m *MockAPIKeyStore) ExpireAPIKey(ctx context.Context, id string, expiresAt time.Time) (bool, error) {
+	key, ok := m.keys[id]
+	if !ok || key.RevokedAt != nil {
+		return false, nil
+	}
+	key.ExpiresAt = &expiresAt
+	return true, nil
+}
+
+func (m *MockAPIKeyStore) RevokeAPIKey(ctx context.Context, id string, revokedAt time.Time) (bool, error) {
+	key, ok := m.keys[id]
+	if !ok || key.RevokedAt != nil {
+		return false, nil
+	}
+	key.RevokedAt = &revokedAt
+	return true, nil
+}
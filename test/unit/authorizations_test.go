@@ -0,0 +1,260 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"apis/payments/services"
+	"apis/payments/services/authorizations"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAuthorizations tests tracking uncaptured charges, capturing them in
+// parts and voiding those about to expire
+func TestAuthorizations(t *testing.T) {
+	ctx := context.Background()
+	config := &authorizations.Config{Enabled: true, Margin: 12 * time.Hour, Interval: time.Minute, BatchSize: 10}
+
+	setup := func() (*authorizations.Service, *MockAuthorizationStore, *MockAuthorizationGateway) {
+		store := &MockAuthorizationStore{authorizations: map[string]*authorizations.Authorization{}}
+		gateway := &MockAuthorizationGateway{}
+		return authorizations.NewService(store, MockAuthorizationGateways{gateway: gateway}, nil, config), store, gateway
+	}
+
+	authorize := func(t *testing.T, service *authorizations.Service, multiCapture bool) *authorizations.Authorization {
+		authorization, err := service.Track(ctx, "tenant_1", &services.Charge{
+			ID:        "ch_1",
+			Amount:    10000,
+			Currency:  "usd",
+			Status:    "requires_capture",
+			Provider:  "stripe",
+			CreatedAt: time.Now(),
+		}, multiCapture)
+		require.NoError(t, err)
+		require.NotNil(t, authorization)
+		return authorization
+	}
+
+	t.Run("should expire after the provider's authorization window", func(t *testing.T) {
+		service, _, _ := setup()
+		created := time.Now().Add(-time.Hour)
+
+		authorization, err := service.Track(ctx, "tenant_1", &services.Charge{
+			ID: "ch_1", Amount: 5000, Status: "requires_capture", Provider: "paypal", CreatedAt: created,
+		}, true)
+		require.NoError(t, err)
+		assert.WithinDuration(t, created.Add(29*24*time.Hour), authorization.ExpiresAt, time.Second)
+		assert.True(t, authorization.MultiCapture)
+
+		captureBefore := time.Now().Add(48 * time.Hour)
+		authorization, err = service.Track(ctx, "tenant_1", &services.Charge{
+			ID: "ch_2", Amount: 5000, Status: "requires_capture", Provider: "square", CreatedAt: created, CaptureBefore: &captureBefore,
+		}, true)
+		require.NoError(t, err)
+		assert.WithinDuration(t, captureBefore, authorization.ExpiresAt, time.Second)
+		assert.False(t, authorization.MultiCapture, "square captures only once")
+	})
+
+	t.Run("should not track captured or already tracked charges", func(t *testing.T) {
+		service, _, _ := setup()
+
+		authorization, err := service.Track(ctx, "tenant_1", &services.Charge{ID: "ch_1", Status: "succeeded", Provider: "stripe"}, false)
+		require.NoError(t, err)
+		assert.Nil(t, authorization)
+
+		authorize(t, service, false)
+		authorization, err = service.Track(ctx, "tenant_1", &services.Charge{ID: "ch_1", Status: "requires_capture", Provider: "stripe"}, false)
+		require.NoError(t, err)
+		assert.Nil(t, authorization)
+	})
+
+	t.Run("should close a single-capture authorization on a partial capture", func(t *testing.T) {
+		service, _, gateway := setup()
+		authorize(t, service, false)
+
+		authorization, _, err := service.Capture(ctx, "tenant_1", "ch_1", services.CaptureChargeRequest{Amount: 4000})
+		require.NoError(t, err)
+		assert.Equal(t, authorizations.StatusCaptured, authorization.Status)
+		assert.Equal(t, int64(4000), authorization.AmountCaptured)
+		assert.Equal(t, []services.CaptureChargeRequest{{Amount: 4000}}, gateway.captures)
+
+		_, _, err = service.Capture(ctx, "tenant_1", "ch_1", services.CaptureChargeRequest{})
+		assert.ErrorIs(t, err, authorizations.ErrAuthorizationClosed)
+	})
+
+	t.Run("should capture a multi-capture authorization in parts", func(t *testing.T) {
+		service, _, gateway := setup()
+		authorize(t, service, true)
+
+		authorization, _, err := service.Capture(ctx, "tenant_1", "ch_1", services.CaptureChargeRequest{Amount: 3000, KeepOpen: true})
+		require.NoError(t, err)
+		assert.True(t, authorization.Open())
+		assert.Equal(t, int64(7000), authorization.Uncaptured())
+
+		authorization, _, err = service.Capture(ctx, "tenant_1", "ch_1", services.CaptureChargeRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, authorizations.StatusCaptured, authorization.Status)
+		assert.Equal(t, int64(10000), authorization.AmountCaptured)
+		assert.Equal(t, 2, authorization.Captures)
+		assert.Equal(t, int64(7000), gateway.captures[1].Amount)
+	})
+
+	t.Run("should reject captures beyond the authorization", func(t *testing.T) {
+		service, _, gateway := setup()
+		authorize(t, service, false)
+
+		_, _, err := service.Capture(ctx, "tenant_1", "ch_1", services.CaptureChargeRequest{Amount: 10001})
+		assert.ErrorIs(t, err, authorizations.ErrExceedsAuthorization)
+
+		_, _, err = service.Capture(ctx, "tenant_1", "ch_1", services.CaptureChargeRequest{Amount: 1000, KeepOpen: true})
+		assert.ErrorIs(t, err, authorizations.ErrSingleCapture)
+		assert.Empty(t, gateway.captures)
+	})
+
+	t.Run("should not expose another tenant's authorization", func(t *testing.T) {
+		service, _, _ := setup()
+		authorize(t, service, false)
+
+		_, _, err := service.Void(ctx, "tenant_2", "ch_1")
+		assert.ErrorIs(t, err, authorizations.ErrAuthorizationNotFound)
+	})
+
+	t.Run("should void authorizations about to expire", func(t *testing.T) {
+		service, store, gateway := setup()
+		authorize(t, service, false)
+		now := time.Now()
+		store.authorizations["ch_1"].ExpiresAt = now.Add(6 * time.Hour)
+
+		closed, err := service.Sweep(ctx, now)
+		require.NoError(t, err)
+		assert.Equal(t, 1, closed)
+		assert.Equal(t, []string{"ch_1"}, gateway.voids)
+		assert.Equal(t, authorizations.StatusVoided, store.authorizations["ch_1"].Status)
+	})
+
+	t.Run("should leave authorizations outside the margin open", func(t *testing.T) {
+		service, store, gateway := setup()
+		authorize(t, service, false)
+		now := time.Now()
+		store.authorizations["ch_1"].ExpiresAt = now.Add(24 * time.Hour)
+
+		closed, err := service.Sweep(ctx, now)
+		require.NoError(t, err)
+		assert.Zero(t, closed)
+		assert.Empty(t, gateway.voids)
+	})
+
+	t.Run("should mark expired authorizations that can no longer be voided", func(t *testing.T) {
+		service, store, gateway := setup()
+		authorize(t, service, false)
+		now := time.Now()
+		store.authorizations["ch_1"].ExpiresAt = now.Add(-time.Hour)
+		gateway.voidErr = errors.New("charge has expired")
+
+		closed, err := service.Sweep(ctx, now)
+		require.NoError(t, err)
+		assert.Equal(t, 1, closed)
+		assert.Equal(t, authorizations.StatusExpired, store.authorizations["ch_1"].Status)
+	})
+
+	t.Run("should record captures made at the provider", func(t *testing.T) {
+		service, store, _ := setup()
+		authorize(t, service, true)
+
+		require.NoError(t, service.Captured(ctx, "ch_1", 2500, time.Now()))
+		assert.True(t, store.authorizations["ch_1"].Open())
+		assert.Equal(t, int64(2500), store.authorizations["ch_1"].AmountCaptured)
+
+		require.NoError(t, service.Captured(ctx, "ch_1", 10000, time.Now()))
+		assert.Equal(t, authorizations.StatusCaptured, store.authorizations["ch_1"].Status)
+	})
+}
+
+// MockAuthorizationStore keeps authorizations in memory
+type MockAuthorizationStore struct {
+	authorizations map[string]*authorizations.Authorization
+}
+
+func (m *MockAuthorizationStore) TrackAuthorization(ctx context.Context, authorization *authorizations.Authorization) (bool, error) {
+	if _, ok := m.authorizations[authorization.ChargeID]; ok {
+		return false, nil
+	}
+	stored := *authorization
+	m.authorizations[authorization.ChargeID] = &stored
+	return true, nil
+}
+
+func (m *MockAuthorizationStore) GetAuthorization(ctx context.Context, chargeID string) (*authorizations.Authorization, error) {
+	authorization, ok := m.authorizations[chargeID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	found := *authorization
+	return &found, nil
+}
+
+func (m *MockAuthorizationStore) ListAuthorizations(ctx context.Context, filter authorizations.Filter) ([]*authorizations.Authorization, error) {
+	var list []*authorizations.Authorization
+	for _, authorization := range m.authorizations {
+		if authorization.TenantID == filter.TenantID && (filter.Status == "" || authorization.Status == filter.Status) {
+			list = append(list, authorization)
+		}
+	}
+	return list, nil
+}
+
+func (m *MockAuthorizationStore) ListStaleAuthorizations(ctx context.Context, expiringBefore time.Time, limit int) ([]*authorizations.Authorization, error) {
+	var stale []*authorizations.Authorization
+	for _, authorization := range m.authorizations {
+		if authorization.Open() && authorization.ExpiresAt.Before(expiringBefore) {
+			found := *authorization
+			stale = append(stale, &found)
+		}
+	}
+	return stale, nil
+}
+
+func (m *MockAuthorizationStore) UpdateAuthorization(ctx context.Context, authorization *authorizations.Authorization) (*authorizations.Authorization, error) {
+	stored, ok := m.authorizations[authorization.ChargeID]
+	if !ok || !stored.Open() {
+		return nil, sql.ErrNoRows
+	}
+	updated := *authorization
+	m.authorizations[authorization.ChargeID] = &updated
+	return &updated, nil
+}
+
+// MockAuthorizationGateways resolves every provider to the same gateway
+type MockAuthorizationGateways struct {
+	gateway *MockAuthorizationGateway
+}
+
+func (m MockAuthorizationGateways) Gateway(ctx context.Context, tenantID, provider string) (services.PaymentGateway, error) {
+	return m.gateway, nil
+}
+
+// MockAuthorizationGateway records captures and voids
+type MockAuthorizationGateway struct {
+	services.PaymentGateway
+	captures []services.CaptureChargeRequest
+	voids    []string
+	voidErr  error
+}
+
+func (m *MockAuthorizationGateway) CaptureCharge(ctx context.Context, chargeID string, req services.CaptureChargeRequest) (*services.Charge, error) {
+	m.captures = append(m.captures, req)
+	return &services.Charge{ID: chargeID, Status: "succeeded"}, nil
+}
+
+func (m *MockAuthorizationGateway) VoidCharge(ctx context.Context, chargeID string) (*services.Charge, error) {
+	if m.voidErr != nil {
+		return nil, m.voidErr
+	}
+	m.voids = append(m.voids, chargeID)
+	return &services.Charge{ID: chargeID, Status: "canceled"}, nil
+}
@@ -0,0 +1,273 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"apis/payments/services/autorefund"
+	"apis/payments/services/events"
+	"apis/payments/services/ledger"
+	"apis/payments/services/stripe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAutoRefund tests refunding unclaimed cash balance funds
+func TestAutoRefund(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, time.October, 16, 12, 0, 0, 0, time.UTC)
+	config := &autorefund.Config{Enabled: true, Window: 30 * 24 * time.Hour, Interval: time.Hour}
+
+	setup := func() (*autorefund.Service, *MockAutoRefundStore, *MockCashBalanceRefunder, *MockLedgerStore, *MockEventPublisher) {
+		store := NewMockAutoRefundStore()
+		refunder := &MockCashBalanceRefunder{}
+		ledgerStore := NewMockLedgerStore()
+		publisher := &MockEventPublisher{}
+		source, _ := events.NewSource("/payments")
+		service := autorefund.NewService(store, refunder, ledger.NewService(ledgerStore), events.NewEmitter(source, publisher), config)
+		return service, store, refunder, ledgerStore, publisher
+	}
+
+	t.Run("should refund balances unclaimed past the window", func(t *testing.T) {
+		service, store, refunder, ledgerStore, publisher := setup()
+		require.NoError(t, service.UpdateBalance(ctx, "cus_1", "eur", 5000, now.Add(-31*24*time.Hour)))
+		require.NoError(t, service.UpdateBalance(ctx, "cus_2", "eur", 2000, now.Add(-5*24*time.Hour)))
+
+		refunds, err := service.Sweep(ctx, now)
+		require.NoError(t, err)
+		require.Len(t, refunds, 1)
+		assert.Equal(t, "cus_1", refunds[0].CustomerID)
+		assert.Equal(t, autorefund.StatusSucceeded, refunds[0].Status)
+		assert.Equal(t, "re_1", refunds[0].RefundID)
+		assert.Equal(t, int64(5000), refunder.requests[0].Amount)
+		assert.Equal(t, int64(0), store.balances["cus_1/eur"].Amount)
+
+		require.Len(t, ledgerStore.entries, 1)
+		assert.Equal(t, ledger.AccountUnclaimedFunds, ledgerStore.entries[0].DebitAccount)
+		assert.Equal(t, ledger.AccountProviderBalance, ledgerStore.entries[0].CreditAccount)
+		assert.Equal(t, refunds[0].ID, ledgerStore.entries[0].ReferenceID)
+
+		require.Len(t, publisher.events, 1)
+		assert.Equal(t, autorefund.EventAutoRefundCreated, publisher.events[0].Type)
+		assert.Equal(t, "/payments/auto-refunds", publisher.events[0].Source)
+	})
+
+	t.Run("should keep the original funding time while funds stay unclaimed", func(t *testing.T) {
+		service, store, _, _, _ := setup()
+		fundedAt := now.Add(-40 * 24 * time.Hour)
+		require.NoError(t, service.UpdateBalance(ctx, "cus_1", "eur", 5000, fundedAt))
+		require.NoError(t, service.UpdateBalance(ctx, "cus_1", "eur", 3000, now.Add(-time.Hour)))
+
+		assert.Equal(t, fundedAt, *store.balances["cus_1/eur"].FundedAt)
+		assert.Equal(t, int64(3000), store.balances["cus_1/eur"].Amount)
+	})
+
+	t.Run("should skip excluded customers", func(t *testing.T) {
+		service, _, refunder, _, _ := setup()
+		require.NoError(t, service.UpdateBalance(ctx, "cus_1", "eur", 5000, now.Add(-31*24*time.Hour)))
+		_, err := service.Exclude(ctx, "cus_1", "awaiting remittance advice")
+		require.NoError(t, err)
+
+		refunds, err := service.Sweep(ctx, now)
+		require.NoError(t, err)
+		assert.Empty(t, refunds)
+		assert.Empty(t, refunder.requests)
+	})
+
+	t.Run("should record failed refunds and retry them", func(t *testing.T) {
+		service, store, refunder, ledgerStore, publisher := setup()
+		require.NoError(t, service.UpdateBalance(ctx, "cus_1", "eur", 5000, now.Add(-31*24*time.Hour)))
+		refunder.err = errors.New("no bank details")
+
+		refunds, err := service.Sweep(ctx, now)
+		require.NoError(t, err)
+		require.Len(t, refunds, 1)
+		assert.Equal(t, autorefund.StatusFailed, refunds[0].Status)
+		assert.Empty(t, ledgerStore.entries)
+		assert.Equal(t, autorefund.EventAutoRefundFailed, publisher.events[0].Type)
+		assert.Equal(t, int64(5000), store.balances["cus_1/eur"].Amount)
+
+		refunder.err = nil
+		refunds, err = service.Sweep(ctx, now)
+		require.NoError(t, err)
+		require.Len(t, refunds, 1)
+		assert.Equal(t, autorefund.StatusSucceeded, refunds[0].Status)
+	})
+
+	t.Run("should report removing a missing exclusion", func(t *testing.T) {
+		service, _, _, _, _ := setup()
+
+		err := service.Include(ctx, "cus_1")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+}
+
+// MockAutoRefundStore is an in-memory autorefund.Store
+type MockAutoRefundStore struct {
+	balances   map[string]*autorefund.Balance
+	refunds    []*autorefund.Refund
+	exclusions map[string]*autorefund.Exclusion
+}
+
+func NewMockAutoRefundStore() *MockAutoRefundStore {
+	return &MockAutoRefundStore{
+		balances:   make(map[string]*autorefund.Balance),
+		exclusions: make(map[string]*autorefund.Exclusion),
+	}
+}
+
+func (m *MockAutoRefundStore) GetUnclaimedBalance(ctx context.Context, customerID, currency string) (*autorefund.Balance, error) {
+	balance, ok := m.balances[customerID+"/"+currency]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return balance, nil
+}
+
+func (m *MockAutoRefundStore) UpsertUnclaimedBalance(ctx context.Context, balance *autorefund.Balance) error {
+	stored := *balance
+	m.balances[balance.CustomerID+"/"+balance.Currency] = &stored
+	return nil
+}
+
+func (m *MockAutoRefundStore) ListDueUnclaimedBalances(ctx context.Context, fundedBefore time.Time) ([]*autorefund.Balance, error) {
+	var balances []*autorefund.Balance
+	for _, balance := range m.balances {
+		if balance.Amount > 0 && balance.FundedAt != nil && !balance.FundedAt.After(fundedBefore) {
+			balances = append(balances, balance)
+		}
+	}
+	return balances, nil
+}
+
+func (m *MockAutoRefundStore) CreateAutoRefund(ctx context.Context, refund *autorefund.Refund) (*autorefund.Refund, error) {
+	m.refunds = append(m.refunds, refund)
+	return refund, nil
+}
+
+func (m *MockAutoRefundStore) ListAutoRefunds(ctx context.Context, customerID string, limit int) ([]*autorefund.Refund, error) {
+	return m.refunds, nil
+}
+
+func (m *MockAutoRefundStore) GetAutoRefundExclusion(ctx context.Context, customerID string) (*autorefund.Exclusion, error) {
+	exclusion, ok := m.exclusions[customerID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return exclusion, nil
+}
+
+func (m *MockAutoRefundStore) ListAutoRefundExclusions(ctx context.Context) ([]*autorefund.Exclusion, error) {
+	var exclusions []*autorefund.Exclusion
+	for _, exclusion := range m.exclusions {
+		exclusions = append(exclusions, exclusion)
+	}
+	return exclusions, nil
+}
+
+func (m *MockAutoRefundStore) UpsertAutoRefundExclusion(ctx context.Context, exclusion *autorefund.Exclusion) (*autorefund.Exclusion, error) {
+	m.exclusions[exclusion.CustomerID] = exclusion
+	return exclusion, nil
+}
+
+func (m *MockAutoRefundStore) DeleteAutoRefundExclusion(ctx context.Context, customerID string) (bool, error) {
+	_, ok := m.exclusions[customerID]
+	delete(m.exclusions, customerID)
+	return ok, nil
+}
+
+// MockCashBalanceRefunder records cash balance refunds
+type MockCashBalanceRefunder struct {
+	requests []*stripe.CashBalanceRefundRequest
+	err      error
+}
+
+func (m *MockCashBalanceRefunder) RefundCashBalance(ctx context.Context, request *stripe.CashBalanceRefundRequest) (*stripe.Refund, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.requests = append(m.requests, request)
+	return &stripe.Refund{ID: "re_1", Amount: request.Amount, Currency: request.Currency, Status: "pending"}, nil
+}
+
+// MockLedgerStore is an in-memory ledger.Store
+type MockLedgerStore struct {
+	entries []*ledger.Entry
+}
+
+func NewMockLedgerStore() *MockLedgerStore {
+	return &MockLedgerStore{}
+}
+
+func (m *MockLedgerStore) CreateLedgerEntry(ctx context.Context, entry *ledger.Entry) error {
+	for _, existing := range m.entries {
+		if existing.ReferenceType == entry.ReferenceType && existing.ReferenceID == entry.ReferenceID &&
+			existing.DebitAccount == entry.DebitAccount && existing.CreditAccount == entry.CreditAccount {
+			return nil
+		}
+	}
+	m.entries = append(m.entries, entry)
+	return nil
+}
+
+func (m *MockLedgerStore) ListLedgerEntriesByReference(ctx context.Context, referenceType, referenceID string) ([]*ledger.Entry, error) {
+	var entries []*ledger.Entry
+	for _, entry := range m.entries {
+		if entry.ReferenceType == referenceType && entry.ReferenceID == referenceID {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func (m *MockLedgerStore) ListLedgerEntriesByAccount(ctx context.Context, account, currency string, limit, offset int) ([]*ledger.Entry, error) {
+	var entries []*ledger.Entry
+	for i := len(m.entries) - 1; i >= 0; i-- {
+		entry := m.entries[i]
+		if (entry.DebitAccount == account || entry.CreditAccount == account) && (currency == "" || entry.Currency == currency) {
+			entries = append(entries, entry)
+		}
+	}
+	if offset >= len(entries) {
+		return nil, nil
+	}
+	return entries[offset:min(offset+limit, len(entries))], nil
+}
+
+func (m *MockLedgerStore) ListLedgerAccountTotals(ctx context.Context, asOf time.Time) ([]*ledger.AccountTotal, error) {
+	totals := make(map[[2]string]*ledger.AccountTotal)
+	total := func(account, currency string) *ledger.AccountTotal {
+		key := [2]string{account, currency}
+		if totals[key] == nil {
+			totals[key] = &ledger.AccountTotal{Account: account, Currency: currency}
+		}
+		return totals[key]
+	}
+	for _, entry := range m.entries {
+		if entry.CreatedAt.After(asOf) {
+			continue
+		}
+		total(entry.DebitAccount, entry.Currency).Debits += entry.Amount
+		total(entry.CreditAccount, entry.Currency).Credits += entry.Amount
+	}
+
+	var result []*ledger.AccountTotal
+	for _, t := range totals {
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+// MockEventPublisher records published events
+type MockEventPublisher struct {
+	events []*events.Event
+}
+
+func (m *MockEventPublisher) Publish(ctx context.Context, event *events.Event) error {
+	m.events = append(m.events, event)
+	return nil
+}
@@ -0,0 +1,93 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"apis/payments/services/backpressure"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBackpressure tests shedding webhook deliveries when processing is saturated
+func TestBackpressure(t *testing.T) {
+	newConfig := func() *backpressure.Config {
+		return &backpressure.Config{
+			MaxInFlight:   2,
+			MaxDBLatency:  100 * time.Millisecond,
+			ProbeInterval: 5 * time.Millisecond,
+			MinRetryAfter: 10 * time.Second,
+			MaxRetryAfter: time.Minute,
+		}
+	}
+
+	t.Run("should shed deliveries once too many are in flight", func(t *testing.T) {
+		monitor := backpressure.NewMonitor(newConfig())
+
+		releaseFirst, rejection := monitor.Acquire()
+		require.Nil(t, rejection)
+		_, rejection = monitor.Acquire()
+		require.Nil(t, rejection)
+
+		_, rejection = monitor.Acquire()
+		require.NotNil(t, rejection)
+		assert.Equal(t, backpressure.ReasonSaturated, rejection.Reason)
+		assert.GreaterOrEqual(t, rejection.RetryAfter, 10*time.Second)
+		assert.LessOrEqual(t, rejection.RetryAfter, time.Minute)
+
+		releaseFirst()
+		releaseFirst()
+		assert.Equal(t, 1, monitor.Status().InFlight)
+
+		_, rejection = monitor.Acquire()
+		assert.Nil(t, rejection)
+	})
+
+	t.Run("should shed deliveries while the database is slow", func(t *testing.T) {
+		monitor := backpressure.NewMonitor(newConfig())
+
+		monitor.ObserveDBLatency(50 * time.Millisecond)
+		_, rejection := monitor.Acquire()
+		assert.Nil(t, rejection)
+
+		for i := 0; i < 10; i++ {
+			monitor.ObserveDBLatency(400 * time.Millisecond)
+		}
+		_, rejection = monitor.Acquire()
+		require.NotNil(t, rejection)
+		assert.Equal(t, backpressure.ReasonDatabaseSlow, rejection.Reason)
+		assert.Equal(t, backpressure.ReasonDatabaseSlow, monitor.Status().Shedding)
+	})
+
+	t.Run("should ask for longer waits the further over the limit", func(t *testing.T) {
+		slightly := backpressure.NewMonitor(newConfig())
+		slightly.ObserveDBLatency(150 * time.Millisecond)
+		_, slight := slightly.Acquire()
+		require.NotNil(t, slight)
+
+		badly := backpressure.NewMonitor(newConfig())
+		badly.ObserveDBLatency(time.Second)
+		_, bad := badly.Acquire()
+		require.NotNil(t, bad)
+
+		// 1.5x and 10x the limit, with up to half again as jitter
+		assert.Less(t, slight.RetryAfter, 23*time.Second)
+		assert.Equal(t, time.Minute, bad.RetryAfter)
+	})
+
+	t.Run("should count failed probes as slow", func(t *testing.T) {
+		monitor := backpressure.NewMonitor(newConfig())
+
+		stop := monitor.Start(func(ctx context.Context) error {
+			return errors.New("connection refused")
+		})
+		defer stop()
+
+		assert.Eventually(t, func() bool {
+			return monitor.Status().Shedding == backpressure.ReasonDatabaseSlow
+		}, time.Second, 5*time.Millisecond)
+	})
+}
@@ -0,0 +1,168 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"apis/payments/services/bankdebits"
+	"apis/payments/services/events"
+	"apis/payments/services/stripe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// TestBankDebits tests ACH, SEPA and BACS payment methods and the
+// asynchronous progress of bank debits
+func TestBankDebits(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func() (*stripe.WebhookService, *MockEventPublisher) {
+		publisher := &MockEventPublisher{}
+		source, err := events.NewSource("/payments")
+		require.NoError(t, err)
+		webhooks := stripe.NewWebhookService("whsec_test")
+		bankdebits.NewService(events.NewEmitter(source, publisher)).RegisterWebhookHandlers(webhooks)
+		return webhooks, publisher
+	}
+	newEvent := func(eventType stripego.EventType, raw string) stripego.Event {
+		return stripego.Event{ID: "evt_1", Type: eventType, Created: 1700000100, Data: &stripego.EventData{Raw: []byte(raw)}}
+	}
+
+	t.Run("should require a bank account and mandate before calling the provider", func(t *testing.T) {
+		customerService := stripe.NewCustomerService()
+
+		_, err := customerService.AddPaymentMethod(ctx, &stripe.PaymentMethodRequest{Type: "sepa_debit", Customer: "cus_1"})
+		assert.ErrorIs(t, err, stripe.ErrBankAccountRequired)
+
+		_, err = customerService.AddPaymentMethod(ctx, &stripe.PaymentMethodRequest{
+			Type:        "bacs_debit",
+			Customer:    "cus_1",
+			BankAccount: &stripe.BankAccountRequest{AccountHolderName: "Jenny Rosen", AccountNumber: "00012345", SortCode: "108800"},
+		})
+		assert.ErrorIs(t, err, stripe.ErrMandateRequired)
+
+		_, err = customerService.AddPaymentMethod(ctx, &stripe.PaymentMethodRequest{
+			Type:        "us_bank_account",
+			Customer:    "cus_1",
+			BankAccount: &stripe.BankAccountRequest{AccountHolderName: "Jenny Rosen", AccountNumber: "000123456789", RoutingNumber: "110000000"},
+			Mandate:     &stripe.MandateAcceptance{Type: "online"},
+		})
+		assert.ErrorContains(t, err, "validation failed", "online mandates need the customer's IP address and user agent")
+
+		_, err = customerService.AddPaymentMethod(ctx, &stripe.PaymentMethodRequest{Type: "card", Customer: "cus_1"})
+		assert.ErrorContains(t, err, "card details are required")
+	})
+
+	t.Run("should verify micro-deposits with amounts or a descriptor code", func(t *testing.T) {
+		customerService := stripe.NewCustomerService()
+
+		_, err := customerService.VerifyMicrodeposits(ctx, "seti_1", &stripe.MicrodepositVerification{})
+		assert.ErrorIs(t, err, stripe.ErrInvalidMicrodeposits)
+
+		_, err = customerService.VerifyMicrodeposits(ctx, "seti_1", &stripe.MicrodepositVerification{Amounts: []int64{32, 45}, DescriptorCode: "SM11AA"})
+		assert.ErrorIs(t, err, stripe.ErrInvalidMicrodeposits)
+	})
+
+	t.Run("should convert bank account details", func(t *testing.T) {
+		ach := stripe.ConvertPaymentMethod(&stripego.PaymentMethod{
+			ID:   "pm_ach",
+			Type: stripego.PaymentMethodTypeUSBankAccount,
+			USBankAccount: &stripego.PaymentMethodUSBankAccount{
+				BankName:          "STRIPE TEST BANK",
+				Last4:             "6789",
+				RoutingNumber:     "110000000",
+				AccountHolderType: stripego.PaymentMethodUSBankAccountAccountHolderTypeIndividual,
+				AccountType:       stripego.PaymentMethodUSBankAccountAccountTypeChecking,
+			},
+		})
+		require.NotNil(t, ach.BankAccount)
+		assert.Nil(t, ach.Card)
+		assert.Equal(t, "6789", ach.BankAccount.Last4)
+		assert.Equal(t, "110000000", ach.BankAccount.RoutingNumber)
+		assert.Equal(t, "checking", ach.BankAccount.AccountType)
+
+		sepa := stripe.ConvertPaymentMethod(&stripego.PaymentMethod{
+			ID:        "pm_sepa",
+			Type:      stripego.PaymentMethodTypeSEPADebit,
+			SEPADebit: &stripego.PaymentMethodSEPADebit{Last4: "3000", Country: "DE", BankCode: "37040044"},
+		})
+		assert.Equal(t, "DE", sepa.BankAccount.Country)
+		assert.Equal(t, "37040044", sepa.BankAccount.BankCode)
+
+		bacs := stripe.ConvertPaymentMethod(&stripego.PaymentMethod{
+			ID:        "pm_bacs",
+			Type:      stripego.PaymentMethodTypeBACSDebit,
+			BACSDebit: &stripego.PaymentMethodBACSDebit{Last4: "2345", SortCode: "108800"},
+		})
+		assert.Equal(t, "108800", bacs.BankAccount.SortCode)
+	})
+
+	t.Run("should show pending micro-deposit verification", func(t *testing.T) {
+		intent := stripe.ConvertSetupIntent(&stripego.SetupIntent{
+			ID:      "seti_1",
+			Status:  stripego.SetupIntentStatusRequiresAction,
+			Mandate: &stripego.Mandate{ID: "mandate_1"},
+			NextAction: &stripego.SetupIntentNextAction{
+				Type: stripego.SetupIntentNextActionTypeVerifyWithMicrodeposits,
+				VerifyWithMicrodeposits: &stripego.SetupIntentNextActionVerifyWithMicrodeposits{
+					ArrivalDate:           1700086400,
+					HostedVerificationURL: "https://payments.stripe.com/microdeposit/sacs_1",
+					MicrodepositType:      stripego.SetupIntentNextActionVerifyWithMicrodepositsMicrodepositTypeDescriptorCode,
+				},
+			},
+		})
+
+		assert.Equal(t, "mandate_1", intent.Mandate)
+		require.NotNil(t, intent.Microdeposits)
+		assert.Equal(t, "descriptor_code", intent.Microdeposits.Type)
+		assert.Equal(t, int64(1700086400), intent.Microdeposits.ArrivalDate)
+	})
+
+	t.Run("should publish the settlement of bank debit charges only", func(t *testing.T) {
+		webhooks, publisher := setup()
+
+		require.NoError(t, webhooks.Dispatch(ctx, newEvent(stripego.EventTypeChargePending,
+			`{"id": "ch_1", "status": "pending", "amount": 5000, "currency": "usd", "payment_method_details": {"type": "us_bank_account"}}`)))
+		require.NoError(t, webhooks.Dispatch(ctx, newEvent(stripego.EventTypeChargeFailed,
+			`{"id": "ch_1", "status": "failed", "amount": 5000, "currency": "usd", "failure_code": "insufficient_funds", "payment_method_details": {"type": "us_bank_account"}}`)))
+		require.NoError(t, webhooks.Dispatch(ctx, newEvent(stripego.EventTypeChargeSucceeded,
+			`{"id": "ch_2", "status": "succeeded", "amount": 5000, "currency": "eur", "payment_method_details": {"type": "sepa_debit"}}`)))
+		require.NoError(t, webhooks.Dispatch(ctx, newEvent(stripego.EventTypeChargeSucceeded,
+			`{"id": "ch_3", "status": "succeeded", "amount": 5000, "currency": "usd", "payment_method_details": {"type": "card"}}`)))
+
+		require.Len(t, publisher.events, 3)
+		assert.Equal(t, bankdebits.EventPending, publisher.events[0].Type)
+		assert.Equal(t, bankdebits.EventFailed, publisher.events[1].Type)
+		assert.Equal(t, bankdebits.EventSettled, publisher.events[2].Type)
+		assert.Equal(t, "ch_2", publisher.events[2].Subject)
+
+		var charge stripe.Charge
+		require.NoError(t, json.Unmarshal(publisher.events[1].Data, &charge))
+		assert.Equal(t, "insufficient_funds", charge.FailureCode)
+		assert.Equal(t, "us_bank_account", charge.PaymentMethodType)
+	})
+
+	t.Run("should publish bank account verification and revoked mandates", func(t *testing.T) {
+		webhooks, publisher := setup()
+
+		require.NoError(t, webhooks.Dispatch(ctx, newEvent(stripego.EventTypeSetupIntentRequiresAction,
+			`{"id": "seti_1", "status": "requires_action", "payment_method_types": ["us_bank_account"], "next_action": {"type": "verify_with_microdeposits", "verify_with_microdeposits": {"arrival_date": 1700086400, "microdeposit_type": "amounts"}}}`)))
+		require.NoError(t, webhooks.Dispatch(ctx, newEvent(stripego.EventTypeSetupIntentSucceeded,
+			`{"id": "seti_1", "status": "succeeded", "payment_method_types": ["us_bank_account"]}`)))
+		require.NoError(t, webhooks.Dispatch(ctx, newEvent(stripego.EventTypeSetupIntentSucceeded,
+			`{"id": "seti_2", "status": "succeeded", "payment_method_types": ["card"]}`)))
+		require.NoError(t, webhooks.Dispatch(ctx, newEvent(stripego.EventTypeMandateUpdated,
+			`{"id": "mandate_1", "status": "active", "type": "multi_use"}`)))
+		require.NoError(t, webhooks.Dispatch(ctx, newEvent(stripego.EventTypeMandateUpdated,
+			`{"id": "mandate_1", "status": "inactive", "type": "multi_use", "payment_method": "pm_1", "payment_method_details": {"type": "sepa_debit"}}`)))
+
+		require.Len(t, publisher.events, 3)
+		assert.Equal(t, bankdebits.EventVerificationRequired, publisher.events[0].Type)
+		assert.Equal(t, bankdebits.EventVerified, publisher.events[1].Type)
+		assert.Equal(t, bankdebits.EventMandateInactive, publisher.events[2].Type)
+		assert.Equal(t, "mandate_1", publisher.events[2].Subject)
+	})
+}
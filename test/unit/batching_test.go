@@ -0,0 +1,132 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"apis/payments/services/batching"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAdaptiveBatching tests AIMD batch size and concurrency control
+func TestAdaptiveBatching(t *testing.T) {
+	limits := func() *batching.Limits {
+		return &batching.Limits{
+			MinSize:        10,
+			MaxSize:        100,
+			InitialSize:    20,
+			Step:           10,
+			MinConcurrency: 1,
+			MaxConcurrency: 4,
+			TargetLatency:  time.Second,
+			MaxErrorRate:   0.1,
+			Backoff:        0.5,
+		}
+	}
+
+	t.Run("should grow additively after healthy full batches", func(t *testing.T) {
+		controller := batching.NewController(limits())
+
+		controller.Observe(batching.Result{Items: 20, Latency: 100 * time.Millisecond})
+		assert.Equal(t, 30, controller.Size())
+		assert.Equal(t, 2, controller.Concurrency())
+
+		// A short final batch says nothing about larger sizes
+		controller.Observe(batching.Result{Items: 5, Latency: 100 * time.Millisecond})
+		assert.Equal(t, 30, controller.Size())
+	})
+
+	t.Run("should back off multiplicatively on slow batches", func(t *testing.T) {
+		config := limits()
+		config.InitialSize = 80
+		controller := batching.NewController(config)
+		controller.Observe(batching.Result{Items: 80})
+		controller.Observe(batching.Result{Items: 90})
+		require.Equal(t, 3, controller.Concurrency())
+
+		controller.Observe(batching.Result{Items: 100, Latency: 2 * time.Second})
+		assert.Equal(t, 50, controller.Size())
+		assert.Equal(t, 1, controller.Concurrency())
+		assert.Equal(t, int64(1), controller.Stats().Decreases)
+	})
+
+	t.Run("should back off when the error rate is too high", func(t *testing.T) {
+		controller := batching.NewController(limits())
+
+		controller.Observe(batching.Result{Items: 20, Failures: 5})
+		assert.Equal(t, 10, controller.Size())
+		assert.Equal(t, 0.25, controller.Stats().LastErrorRate)
+	})
+
+	t.Run("should stay within the floors and ceilings", func(t *testing.T) {
+		controller := batching.NewController(limits())
+
+		for i := 0; i < 20; i++ {
+			controller.Observe(batching.Result{Items: controller.Size()})
+		}
+		assert.Equal(t, 100, controller.Size())
+		assert.Equal(t, 4, controller.Concurrency())
+
+		for i := 0; i < 20; i++ {
+			controller.Observe(batching.Result{Items: controller.Size(), Latency: time.Minute})
+		}
+		assert.Equal(t, 10, controller.Size())
+		assert.Equal(t, 1, controller.Concurrency())
+	})
+
+	t.Run("should repair unsatisfiable limits", func(t *testing.T) {
+		controller := batching.NewController(&batching.Limits{MinSize: 50, MaxSize: 10, InitialSize: 500})
+
+		assert.Equal(t, 50, controller.Size())
+		assert.Equal(t, 1, controller.Concurrency())
+	})
+
+	t.Run("should process every item in adaptive batches", func(t *testing.T) {
+		controller := batching.NewController(limits())
+		items := make([]int, 250)
+		for i := range items {
+			items[i] = i
+		}
+
+		var mu sync.Mutex
+		seen := make(map[int]bool)
+		processed, err := batching.Process(context.Background(), controller, items, func(ctx context.Context, batch []int) (int, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			for _, item := range batch {
+				seen[item] = true
+			}
+			return 0, nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, 250, processed)
+		assert.Len(t, seen, 250)
+		assert.Greater(t, controller.Size(), 20)
+	})
+
+	t.Run("should stop at the first failed round", func(t *testing.T) {
+		config := limits()
+		config.MaxConcurrency = 1
+		controller := batching.NewController(config)
+		items := make([]int, 100)
+
+		calls := 0
+		processed, err := batching.Process(context.Background(), controller, items, func(ctx context.Context, batch []int) (int, error) {
+			calls++
+			if calls == 2 {
+				return 0, errors.New("store unavailable")
+			}
+			return 0, nil
+		})
+
+		assert.EqualError(t, err, "store unavailable")
+		assert.Equal(t, 20, processed)
+		assert.Equal(t, 2, calls)
+	})
+}
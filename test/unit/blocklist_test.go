@@ -0,0 +1,237 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"apis/payments/services/blocklist"
+	"apis/payments/services/stripe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBlocklist tests blocking values and keeping them in sync with the provider
+func TestBlocklist(t *testing.T) {
+	setup := func() (*blocklist.Service, *MockBlocklistStore, *MockBlocklistMirror) {
+		store := NewMockBlocklistStore()
+		mirror := NewMockBlocklistMirror()
+		customers := &MockProjectionSources{
+			customers: map[string]*stripe.Customer{
+				"cus_1": {ID: "cus_1", Email: "Fraud@Example.com"},
+				"cus_2": {ID: "cus_2", Email: "jane@example.com"},
+			},
+		}
+		service := blocklist.NewService(store, mirror, customers, blocklist.LoadConfig())
+		return service, store, mirror
+	}
+
+	t.Run("should normalize and mirror new entries", func(t *testing.T) {
+		service, store, mirror := setup()
+
+		entry, err := service.Add(context.Background(), &blocklist.Entry{Type: blocklist.TypeEmail, Value: " Fraud@Example.com "})
+		require.NoError(t, err)
+		assert.Equal(t, "fraud@example.com", entry.Value)
+		assert.Equal(t, blocklist.SourceAPI, entry.Source)
+		assert.NotEmpty(t, entry.ProviderItemID)
+		assert.Equal(t, entry.ProviderItemID, store.entries[entry.ID].ProviderItemID)
+		assert.Len(t, mirror.items[blocklist.TypeEmail], 1)
+	})
+
+	t.Run("should reject unknown types and empty values", func(t *testing.T) {
+		service, _, _ := setup()
+
+		_, err := service.Add(context.Background(), &blocklist.Entry{Type: "ip_address", Value: "10.0.0.1"})
+		assert.ErrorIs(t, err, blocklist.ErrInvalidEntry)
+
+		_, err = service.Add(context.Background(), &blocklist.Entry{Type: blocklist.TypeEmail, Value: "  "})
+		assert.ErrorIs(t, err, blocklist.ErrInvalidEntry)
+	})
+
+	t.Run("should keep entries that fail to mirror for the next sync", func(t *testing.T) {
+		service, store, mirror := setup()
+		mirror.err = errors.New("provider unavailable")
+
+		entry, err := service.Add(context.Background(), &blocklist.Entry{Type: blocklist.TypeEmail, Value: "fraud@example.com"})
+		require.NoError(t, err)
+		assert.Empty(t, store.entries[entry.ID].ProviderItemID)
+		assert.ErrorIs(t, service.Check(context.Background(), blocklist.TypeEmail, "fraud@example.com"), blocklist.ErrBlocked)
+
+		mirror.err = nil
+		result, err := service.Sync(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Pushed)
+		assert.NotEmpty(t, store.entries[entry.ID].ProviderItemID)
+	})
+
+	t.Run("should block charges for customers with a blocked email", func(t *testing.T) {
+		service, _, _ := setup()
+		_, err := service.Add(context.Background(), &blocklist.Entry{Type: blocklist.TypeEmail, Value: "fraud@example.com"})
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, service.CheckCharge(context.Background(), "cus_1"), blocklist.ErrBlocked)
+		assert.NoError(t, service.CheckCharge(context.Background(), "cus_2"))
+	})
+
+	t.Run("should remove entries at the provider first", func(t *testing.T) {
+		service, store, mirror := setup()
+		entry, err := service.Add(context.Background(), &blocklist.Entry{Type: blocklist.TypeCardFingerprint, Value: "fp_1"})
+		require.NoError(t, err)
+
+		mirror.err = errors.New("provider unavailable")
+		assert.Error(t, service.Remove(context.Background(), entry.ID))
+		assert.Contains(t, store.entries, entry.ID)
+
+		mirror.err = nil
+		require.NoError(t, service.Remove(context.Background(), entry.ID))
+		assert.NotContains(t, store.entries, entry.ID)
+		assert.Empty(t, mirror.items[blocklist.TypeCardFingerprint])
+
+		assert.ErrorIs(t, service.Remove(context.Background(), entry.ID), blocklist.ErrEntryNotFound)
+	})
+
+	t.Run("should import values added at the provider", func(t *testing.T) {
+		service, store, mirror := setup()
+		mirror.items[blocklist.TypeCardFingerprint] = []*blocklist.MirrorItem{{ID: "rsli_dash", Value: "fp_dash"}}
+
+		result, err := service.Sync(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Imported)
+
+		entry, err := store.GetBlocklistEntryByValue(context.Background(), blocklist.TypeCardFingerprint, "fp_dash")
+		require.NoError(t, err)
+		assert.Equal(t, blocklist.SourceProvider, entry.Source)
+		assert.Equal(t, "rsli_dash", entry.ProviderItemID)
+	})
+
+	t.Run("should remove entries deleted at the provider", func(t *testing.T) {
+		service, store, mirror := setup()
+		entry, err := service.Add(context.Background(), &blocklist.Entry{Type: blocklist.TypeEmail, Value: "fraud@example.com"})
+		require.NoError(t, err)
+		mirror.items[blocklist.TypeEmail] = nil
+
+		result, err := service.Sync(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Removed)
+		assert.NotContains(t, store.entries, entry.ID)
+	})
+
+	t.Run("should relink entries re-added at the provider", func(t *testing.T) {
+		service, store, mirror := setup()
+		entry, err := service.Add(context.Background(), &blocklist.Entry{Type: blocklist.TypeEmail, Value: "fraud@example.com"})
+		require.NoError(t, err)
+		mirror.items[blocklist.TypeEmail] = []*blocklist.MirrorItem{{ID: "rsli_new", Value: "FRAUD@example.com"}}
+
+		result, err := service.Sync(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Linked)
+		assert.Zero(t, result.Imported)
+		assert.Equal(t, "rsli_new", store.entries[entry.ID].ProviderItemID)
+	})
+}
+
+// MockBlocklistStore keeps blocklist entries in memory
+type MockBlocklistStore struct {
+	entries map[string]*blocklist.Entry
+}
+
+// NewMockBlocklistStore creates an empty blocklist store
+func NewMockBlocklistStore() *MockBlocklistStore {
+	return &MockBlocklistStore{entries: make(map[string]*blocklist.Entry)}
+}
+
+func (m *MockBlocklistStore) CreateBlocklistEntry(ctx context.Context, entry *blocklist.Entry) (*blocklist.Entry, error) {
+	if existing, err := m.GetBlocklistEntryByValue(ctx, entry.Type, entry.Value); err == nil {
+		return existing, nil
+	}
+	stored := *entry
+	m.entries[entry.ID] = &stored
+	return &stored, nil
+}
+
+func (m *MockBlocklistStore) GetBlocklistEntry(ctx context.Context, id string) (*blocklist.Entry, error) {
+	entry, ok := m.entries[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return entry, nil
+}
+
+func (m *MockBlocklistStore) GetBlocklistEntryByValue(ctx context.Context, entryType, value string) (*blocklist.Entry, error) {
+	for _, entry := range m.entries {
+		if entry.Type == entryType && entry.Value == value {
+			return entry, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *MockBlocklistStore) ListBlocklistEntries(ctx context.Context, entryType string) ([]*blocklist.Entry, error) {
+	var entries []*blocklist.Entry
+	for _, entry := range m.entries {
+		if entryType == "" || entry.Type == entryType {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func (m *MockBlocklistStore) SetBlocklistEntryProviderItem(ctx context.Context, id, providerItemID string) error {
+	if entry, ok := m.entries[id]; ok {
+		entry.ProviderItemID = providerItemID
+	}
+	return nil
+}
+
+func (m *MockBlocklistStore) DeleteBlocklistEntry(ctx context.Context, id string) (bool, error) {
+	_, ok := m.entries[id]
+	delete(m.entries, id)
+	return ok, nil
+}
+
+// MockBlocklistMirror is an in-memory provider value list per entry type
+type MockBlocklistMirror struct {
+	items map[string][]*blocklist.MirrorItem
+	next  int
+	err   error
+}
+
+// NewMockBlocklistMirror creates an empty mirror
+func NewMockBlocklistMirror() *MockBlocklistMirror {
+	return &MockBlocklistMirror{items: make(map[string][]*blocklist.MirrorItem)}
+}
+
+func (m *MockBlocklistMirror) AddItem(ctx context.Context, entryType, value string) (string, error) {
+	if m.err != nil {
+		return "", m.err
+	}
+	m.next++
+	item := &blocklist.MirrorItem{ID: fmt.Sprintf("rsli_%d", m.next), Value: value}
+	m.items[entryType] = append(m.items[entryType], item)
+	return item.ID, nil
+}
+
+func (m *MockBlocklistMirror) RemoveItem(ctx context.Context, itemID string) error {
+	if m.err != nil {
+		return m.err
+	}
+	for entryType, items := range m.items {
+		for i, item := range items {
+			if item.ID == itemID {
+				m.items[entryType] = append(items[:i], items[i+1:]...)
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+func (m *MockBlocklistMirror) ListItems(ctx context.Context, entryType string) ([]*blocklist.MirrorItem, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.items[entryType], nil
+}
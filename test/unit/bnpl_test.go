@@ -0,0 +1,135 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"apis/payments/services"
+	"apis/payments/services/stripe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// TestBNPL tests buy-now-pay-later payments authorized by redirect and
+// captured within the lender's window
+func TestBNPL(t *testing.T) {
+	ctx := context.Background()
+
+	klarna := func() *stripe.PaymentIntentRequest {
+		return &stripe.PaymentIntentRequest{
+			Amount:            12000,
+			Currency:          "eur",
+			CustomerID:        "cus_1",
+			PaymentMethodType: stripe.PaymentMethodTypeKlarna,
+			ReturnURL:         "https://shop.example.com/checkout/complete",
+			Customer: &stripe.BNPLCustomer{
+				Name:           "Jenny Rosen",
+				Email:          "jenny@example.com",
+				DateOfBirth:    &stripe.DateOfBirth{Day: 14, Month: 3, Year: 1988},
+				BillingAddress: &stripe.Address{Line1: "Unter den Linden 1", City: "Berlin", PostalCode: "10117", Country: "DE"},
+			},
+		}
+	}
+
+	t.Run("should require the customer details each lender needs", func(t *testing.T) {
+		charges := stripe.NewChargeService()
+		assert.NoError(t, charges.ValidatePaymentIntentRequest(klarna()))
+
+		request := klarna()
+		request.Customer.DateOfBirth = nil
+		assert.ErrorIs(t, charges.ValidatePaymentIntentRequest(request), stripe.ErrDateOfBirthRequired)
+
+		request = klarna()
+		request.PaymentMethodType = stripe.PaymentMethodTypeAfterpay
+		request.Customer.DateOfBirth = nil
+		assert.ErrorIs(t, charges.ValidatePaymentIntentRequest(request), stripe.ErrShippingRequired)
+		request.Customer.ShippingAddress = request.Customer.BillingAddress
+		assert.NoError(t, charges.ValidatePaymentIntentRequest(request))
+
+		request = klarna()
+		request.Customer.BillingAddress = nil
+		assert.ErrorContains(t, charges.ValidatePaymentIntentRequest(request), "validation failed")
+
+		request = klarna()
+		request.ReturnURL = ""
+		assert.ErrorContains(t, charges.ValidatePaymentIntentRequest(request), "validation failed")
+
+		request = klarna()
+		request.PaymentMethodType = "card"
+		assert.ErrorContains(t, charges.ValidatePaymentIntentRequest(request), "validation failed")
+	})
+
+	t.Run("should screen the customer before creating the payment", func(t *testing.T) {
+		charges := stripe.NewChargeService()
+		charges.AddChargeGuard(&MockBNPLChargeGuard{err: errors.New("customer is on hold")})
+
+		_, err := charges.CreatePaymentIntent(ctx, klarna())
+		assert.ErrorContains(t, err, "customer is on hold")
+	})
+
+	t.Run("should show where to redirect the customer", func(t *testing.T) {
+		intent := stripe.ConvertPaymentIntent(&stripego.PaymentIntent{
+			ID:                 "pi_1",
+			Amount:             12000,
+			Currency:           "eur",
+			Status:             stripego.PaymentIntentStatusRequiresAction,
+			CaptureMethod:      stripego.PaymentIntentCaptureMethodManual,
+			PaymentMethodTypes: []string{"klarna"},
+			NextAction: &stripego.PaymentIntentNextAction{
+				Type:          stripego.PaymentIntentNextActionTypeRedirectToURL,
+				RedirectToURL: &stripego.PaymentIntentNextActionRedirectToURL{URL: "https://pay.klarna.com/session/1"},
+			},
+		})
+
+		assert.Equal(t, "https://pay.klarna.com/session/1", intent.RedirectURL)
+		assert.Equal(t, stripe.PaymentMethodTypeKlarna, intent.PaymentMethodType)
+		assert.Zero(t, intent.CaptureBefore, "nothing to capture before authorization")
+	})
+
+	t.Run("should report when an authorization must be captured", func(t *testing.T) {
+		authorized := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+		intent := stripe.ConvertPaymentIntent(&stripego.PaymentIntent{
+			ID:               "pi_1",
+			Status:           stripego.PaymentIntentStatusRequiresCapture,
+			AmountCapturable: 12000,
+			PaymentMethod:    &stripego.PaymentMethod{ID: "pm_1", Type: stripego.PaymentMethodTypeAfterpayClearpay},
+			LatestCharge:     &stripego.Charge{ID: "ch_1", Created: authorized.Unix()},
+		})
+
+		assert.Equal(t, "ch_1", intent.ChargeID)
+		assert.Equal(t, authorized.Add(13*24*time.Hour).Unix(), intent.CaptureBefore)
+		assert.True(t, stripe.IsBNPL(intent.PaymentMethodType))
+		assert.False(t, stripe.IsBNPL(stripe.PaymentMethodTypeSEPA))
+	})
+
+	t.Run("should advertise lenders in the currencies they accept", func(t *testing.T) {
+		capabilities, err := services.Capabilities("stripe")
+		require.NoError(t, err)
+
+		method, ok := capabilities.BNPLMethod(stripe.PaymentMethodTypeKlarna, "eur")
+		require.True(t, ok)
+		assert.Equal(t, 28*24*time.Hour, method.CaptureWindow)
+
+		_, ok = capabilities.BNPLMethod(stripe.PaymentMethodTypeAfterpay, "eur")
+		assert.False(t, ok, "afterpay doesn't take euros")
+
+		paddle, err := services.Capabilities("paddle")
+		require.NoError(t, err)
+		_, ok = paddle.BNPLMethod(stripe.PaymentMethodTypeKlarna, "eur")
+		assert.False(t, ok)
+	})
+}
+
+// MockBNPLChargeGuard blocks every charge with err
+type MockBNPLChargeGuard struct {
+	err error
+}
+
+func (m *MockBNPLChargeGuard) CheckCharge(ctx context.Context, customerID string) error {
+	return m.err
+}
@@ -0,0 +1,194 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"apis/payments/services/budgets"
+	"apis/payments/services/routing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTenantBudgets tests monthly budget alerts and hard-stop enforcement
+func TestTenantBudgets(t *testing.T) {
+	setup := func() (*budgets.Service, *MockBudgetStore, *MockBudgetAlerter) {
+		store := NewMockBudgetStore()
+		alerter := &MockBudgetAlerter{}
+		router := routing.NewRouter(&MockRoutingStore{}, &routing.Config{
+			DefaultProvider: "stripe",
+			BaseCurrency:    "usd",
+			Rates:           map[string]float64{"eur": 1.1},
+		})
+		return budgets.NewService(store, router, alerter), store, alerter
+	}
+
+	t.Run("should default and sort thresholds", func(t *testing.T) {
+		service, _, _ := setup()
+
+		budget, err := service.Set(context.Background(), &budgets.Budget{TenantID: "acme", MonthlyLimit: 10000})
+		require.NoError(t, err)
+		assert.Equal(t, []int{50, 80, 100}, budget.Thresholds)
+
+		budget, err = service.Set(context.Background(), &budgets.Budget{TenantID: "acme", MonthlyLimit: 10000, Thresholds: []int{90, 25}})
+		require.NoError(t, err)
+		assert.Equal(t, []int{25, 90}, budget.Thresholds)
+	})
+
+	t.Run("should reject invalid budgets", func(t *testing.T) {
+		service, _, _ := setup()
+
+		_, err := service.Set(context.Background(), &budgets.Budget{TenantID: "acme"})
+		assert.ErrorIs(t, err, budgets.ErrInvalidBudget)
+
+		_, err = service.Set(context.Background(), &budgets.Budget{TenantID: "acme", MonthlyLimit: 100, Thresholds: []int{80, 80}})
+		assert.ErrorIs(t, err, budgets.ErrInvalidBudget)
+	})
+
+	t.Run("should alert once per threshold crossed", func(t *testing.T) {
+		service, _, alerter := setup()
+		_, err := service.Set(context.Background(), &budgets.Budget{TenantID: "acme", MonthlyLimit: 10000})
+		require.NoError(t, err)
+
+		require.NoError(t, service.RecordCharge(context.Background(), "acme", "usd", 4000))
+		assert.Empty(t, alerter.alerts)
+
+		require.NoError(t, service.RecordCharge(context.Background(), "acme", "usd", 4500))
+		require.Len(t, alerter.alerts, 2)
+		assert.Equal(t, 50, alerter.alerts[0].Threshold)
+		assert.Equal(t, 80, alerter.alerts[1].Threshold)
+		assert.Equal(t, int64(8500), alerter.alerts[1].Spent)
+
+		require.NoError(t, service.RecordCharge(context.Background(), "acme", "usd", 100))
+		assert.Len(t, alerter.alerts, 2)
+	})
+
+	t.Run("should convert charges to the base currency", func(t *testing.T) {
+		service, store, _ := setup()
+
+		require.NoError(t, service.RecordCharge(context.Background(), "acme", "eur", 1000))
+		assert.Equal(t, int64(1100), store.spend["acme"].Amount)
+
+		err := service.RecordCharge(context.Background(), "acme", "gbp", 1000)
+		assert.ErrorIs(t, err, budgets.ErrNoExchangeRate)
+	})
+
+	t.Run("should only block charges past the cap with a hard stop", func(t *testing.T) {
+		service, _, _ := setup()
+		_, err := service.Set(context.Background(), &budgets.Budget{TenantID: "acme", MonthlyLimit: 10000})
+		require.NoError(t, err)
+		require.NoError(t, service.RecordCharge(context.Background(), "acme", "usd", 9000))
+
+		assert.NoError(t, service.CheckCharge(context.Background(), "acme", "usd", 5000))
+
+		_, err = service.Set(context.Background(), &budgets.Budget{TenantID: "acme", MonthlyLimit: 10000, HardStop: true})
+		require.NoError(t, err)
+		assert.NoError(t, service.CheckCharge(context.Background(), "acme", "usd", 1000))
+		assert.ErrorIs(t, service.CheckCharge(context.Background(), "acme", "usd", 1001), budgets.ErrBudgetExceeded)
+	})
+
+	t.Run("should lift the hard stop when the limit is raised", func(t *testing.T) {
+		service, _, _ := setup()
+		_, err := service.Set(context.Background(), &budgets.Budget{TenantID: "acme", MonthlyLimit: 10000, HardStop: true})
+		require.NoError(t, err)
+		require.NoError(t, service.RecordCharge(context.Background(), "acme", "usd", 10000))
+
+		status, err := service.Status(context.Background(), "acme")
+		require.NoError(t, err)
+		assert.True(t, status.Blocked)
+		assert.Equal(t, float64(100), status.PercentUsed)
+
+		_, err = service.Set(context.Background(), &budgets.Budget{TenantID: "acme", MonthlyLimit: 20000, HardStop: true})
+		require.NoError(t, err)
+		assert.NoError(t, service.CheckCharge(context.Background(), "acme", "usd", 5000))
+	})
+
+	t.Run("should allow charges for tenants without a budget", func(t *testing.T) {
+		service, _, _ := setup()
+
+		assert.NoError(t, service.CheckCharge(context.Background(), "acme", "gbp", 1000000))
+
+		status, err := service.Status(context.Background(), "acme")
+		require.NoError(t, err)
+		assert.Nil(t, status.Budget)
+		assert.Equal(t, "usd", status.Currency)
+	})
+}
+
+// MockBudgetStore keeps budgets, spend and alerted thresholds in memory. Spend
+// is keyed by tenant since tests run within a single month.
+type MockBudgetStore struct {
+	budgets map[string]*budgets.Budget
+	spend   map[string]*budgets.Spend
+	alerted map[string]map[int]bool
+}
+
+// NewMockBudgetStore creates an empty budget store
+func NewMockBudgetStore() *MockBudgetStore {
+	return &MockBudgetStore{
+		budgets: make(map[string]*budgets.Budget),
+		spend:   make(map[string]*budgets.Spend),
+		alerted: make(map[string]map[int]bool),
+	}
+}
+
+func (m *MockBudgetStore) GetTenantBudget(ctx context.Context, tenantID string) (*budgets.Budget, error) {
+	budget, ok := m.budgets[tenantID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return budget, nil
+}
+
+func (m *MockBudgetStore) UpsertTenantBudget(ctx context.Context, budget *budgets.Budget) (*budgets.Budget, error) {
+	m.budgets[budget.TenantID] = budget
+	return budget, nil
+}
+
+func (m *MockBudgetStore) DeleteTenantBudget(ctx context.Context, tenantID string) (bool, error) {
+	_, ok := m.budgets[tenantID]
+	delete(m.budgets, tenantID)
+	return ok, nil
+}
+
+func (m *MockBudgetStore) GetTenantSpend(ctx context.Context, tenantID, period string) (*budgets.Spend, error) {
+	spend, ok := m.spend[tenantID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return spend, nil
+}
+
+func (m *MockBudgetStore) AddTenantSpend(ctx context.Context, tenantID, period string, amount int64) (*budgets.Spend, error) {
+	spend, ok := m.spend[tenantID]
+	if !ok {
+		spend = &budgets.Spend{TenantID: tenantID, Period: period}
+		m.spend[tenantID] = spend
+	}
+	spend.Amount += amount
+	spend.ChargeCount++
+	return spend, nil
+}
+
+func (m *MockBudgetStore) RecordBudgetAlert(ctx context.Context, tenantID, period string, threshold int) (bool, error) {
+	if m.alerted[tenantID] == nil {
+		m.alerted[tenantID] = make(map[int]bool)
+	}
+	if m.alerted[tenantID][threshold] {
+		return false, nil
+	}
+	m.alerted[tenantID][threshold] = true
+	return true, nil
+}
+
+// MockBudgetAlerter records the alerts it receives
+type MockBudgetAlerter struct {
+	alerts []*budgets.Alert
+}
+
+func (m *MockBudgetAlerter) Alert(ctx context.Context, alert *budgets.Alert) error {
+	m.alerts = append(m.alerts, alert)
+	return nil
+}
@@ -0,0 +1,207 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"apis/payments/services/cache"
+	"apis/payments/services/stripe"
+	"apis/payments/services/tenancy"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// TestCache tests reading customers and charges through the cache,
+// invalidating them on writes and webhooks, and counting hits and misses
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(store cache.Store) (*cache.Reader[stripe.Customer], *cache.Metrics, *int) {
+		loads := 0
+		metrics := cache.NewMetrics()
+		reader := cache.NewReader(store, metrics, cache.KindCustomer, time.Minute, func(ctx context.Context, id string) (*stripe.Customer, error) {
+			loads++
+			if id == "cus_missing" {
+				return nil, errors.New("no such customer")
+			}
+			return &stripe.Customer{ID: id, Email: "jane@example.com"}, nil
+		})
+		return reader, metrics, &loads
+	}
+
+	t.Run("should serve cached reads until invalidated", func(t *testing.T) {
+		store := &MockCacheStore{}
+		reader, metrics, loads := setup(store)
+
+		for range 3 {
+			customer, err := reader.Get(ctx, "cus_1")
+			require.NoError(t, err)
+			assert.Equal(t, "jane@example.com", customer.Email)
+		}
+		assert.Equal(t, 1, *loads)
+		assert.Equal(t, time.Minute, store.ttls["cache:customer:default:cus_1"])
+
+		reader.Invalidate(ctx, "cus_1")
+		_, err := reader.Get(ctx, "cus_1")
+		require.NoError(t, err)
+		assert.Equal(t, 2, *loads)
+
+		_, err = reader.Get(ctx, "cus_missing")
+		assert.Error(t, err)
+		assert.NotContains(t, store.values, "cache:customer:default:cus_missing", "failed loads are not cached")
+
+		stats := metrics.Snapshot()
+		require.Len(t, stats, 1)
+		assert.Equal(t, int64(2), stats[0].Hits)
+		assert.Equal(t, int64(3), stats[0].Misses)
+		assert.Equal(t, int64(1), stats[0].Invalidations)
+		assert.InDelta(t, 0.4, stats[0].HitRate, 0.001)
+	})
+
+	t.Run("should keep each tenant's objects apart", func(t *testing.T) {
+		store := &MockCacheStore{}
+		reader, _, loads := setup(store)
+		acme := tenancy.WithTenant(ctx, "acme")
+
+		_, err := reader.Get(acme, "cus_1")
+		require.NoError(t, err)
+		_, err = reader.Get(ctx, "cus_1")
+		require.NoError(t, err)
+		assert.Equal(t, 2, *loads, "another tenant's cached object is not served")
+		assert.Contains(t, store.values, "cache:customer:acme:cus_1")
+
+		reader.Invalidate(acme, "cus_1")
+		assert.NotContains(t, store.values, "cache:customer:acme:cus_1")
+		assert.Contains(t, store.values, "cache:customer:default:cus_1")
+	})
+
+	t.Run("should read from the provider when the store fails or is disabled", func(t *testing.T) {
+		reader, metrics, loads := setup(&MockCacheStore{err: errors.New("connection refused")})
+		customer, err := reader.Get(ctx, "cus_1")
+		require.NoError(t, err)
+		assert.Equal(t, "cus_1", customer.ID)
+		assert.Equal(t, int64(2), metrics.Snapshot()[0].Errors, "the failed read and write are counted")
+
+		reader, metrics, loads = setup(nil)
+		_, err = reader.Get(ctx, "cus_1")
+		require.NoError(t, err)
+		reader.Invalidate(ctx, "cus_1")
+		assert.Equal(t, 1, *loads)
+		assert.Empty(t, metrics.Snapshot())
+	})
+
+	t.Run("should invalidate customers and charges the provider reports changed", func(t *testing.T) {
+		webhooks := stripe.NewWebhookService("whsec_test")
+		customers := &MockCacheInvalidations{}
+		charges := &MockCacheInvalidations{}
+		cache.RegisterWebhookHandlers(webhooks, customers, charges, MockChargeTenants{})
+
+		for _, event := range []stripego.Event{
+			{Type: stripego.EventTypeCustomerUpdated, Data: &stripego.EventData{Raw: []byte(`{"id":"cus_1","object":"customer"}`)}},
+			{Type: stripego.EventTypeChargeCaptured, Data: &stripego.EventData{Raw: []byte(`{"id":"ch_1","object":"charge","metadata":{"tenant_id":"acme"}}`)}},
+			{Type: stripego.EventTypeChargeRefundUpdated, Data: &stripego.EventData{Raw: []byte(`{"id":"re_1","object":"refund","charge":"ch_2"}`)}},
+			{Type: stripego.EventTypeChargeDisputeCreated, Data: &stripego.EventData{Raw: []byte(`{"id":"dp_1","object":"dispute","charge":{"id":"ch_3"}}`)}},
+		} {
+			require.NoError(t, webhooks.Dispatch(ctx, event))
+		}
+
+		assert.Equal(t, []string{"cus_1"}, customers.ids)
+		assert.Equal(t, []string{"ch_1", "ch_2", "ch_3"}, charges.ids)
+		assert.Equal(t, []string{"acme", "default", "default"}, charges.tenants)
+	})
+
+	t.Run("should invalidate the charges of refunds and disputes for the charge's tenant", func(t *testing.T) {
+		webhooks := stripe.NewWebhookService("whsec_test")
+		charges := &MockCacheInvalidations{}
+		cache.RegisterWebhookHandlers(webhooks, &MockCacheInvalidations{}, charges, MockChargeTenants{"ch_1": "acme", "ch_2": "globex"})
+
+		for _, event := range []stripego.Event{
+			{Type: stripego.EventTypeChargeDisputeCreated, Data: &stripego.EventData{Raw: []byte(`{"id":"dp_1","object":"dispute","charge":"ch_1"}`)}},
+			{Type: stripego.EventTypeChargeRefundUpdated, Data: &stripego.EventData{Raw: []byte(`{"id":"re_1","object":"refund","charge":"ch_2"}`)}},
+			{Type: stripego.EventTypeChargeDisputeClosed, Data: &stripego.EventData{Raw: []byte(`{"id":"dp_2","object":"dispute","charge":{"id":"ch_3","metadata":{"tenant_id":"initech"}}}`)}},
+			{Type: stripego.EventTypeChargeDisputeClosed, Data: &stripego.EventData{Raw: []byte(`{"id":"dp_3","object":"dispute","charge":"ch_4"}`)}},
+		} {
+			require.NoError(t, webhooks.Dispatch(ctx, event))
+		}
+
+		assert.Equal(t, []string{"ch_1", "ch_2", "ch_3", "ch_4"}, charges.ids)
+		assert.Equal(t, []string{"acme", "globex", "initech", "default"}, charges.tenants)
+	})
+}
+
+// MockCacheStore keeps cached values in memory, failing every call with err
+type MockCacheStore struct {
+	mu     sync.Mutex
+	values map[string][]byte
+	ttls   map[string]time.Duration
+	err    error
+}
+
+func (m *MockCacheStore) Get(ctx context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return nil, m.err
+	}
+	value, ok := m.values[key]
+	if !ok {
+		return nil, cache.ErrMiss
+	}
+	return value, nil
+}
+
+func (m *MockCacheStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	if m.values == nil {
+		m.values = map[string][]byte{}
+		m.ttls = map[string]time.Duration{}
+	}
+	m.values[key] = value
+	m.ttls[key] = ttl
+	return nil
+}
+
+func (m *MockCacheStore) Delete(ctx context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	for _, key := range keys {
+		delete(m.values, key)
+	}
+	return nil
+}
+
+// MockChargeTenants maps locally stored charges to their tenants
+type MockChargeTenants map[string]string
+
+func (m MockChargeTenants) GetChargeTenant(ctx context.Context, chargeID string) (string, error) {
+	tenantID, ok := m[chargeID]
+	if !ok {
+		return "", fmt.Errorf("failed to get charge tenant: %w", sql.ErrNoRows)
+	}
+	return tenantID, nil
+}
+
+// MockCacheInvalidations records invalidated IDs
+type MockCacheInvalidations struct {
+	ids     []string
+	tenants []string
+}
+
+func (m *MockCacheInvalidations) Invalidate(ctx context.Context, ids ...string) {
+	m.ids = append(m.ids, ids...)
+	m.tenants = append(m.tenants, tenancy.ID(ctx))
+}
@@ -0,0 +1,103 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"apis/payments/services/cardupdates"
+	"apis/payments/services/events"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// TestCardUpdates tests following cards refreshed by the card account
+// updater or edited, so billing retries on the new card
+func TestCardUpdates(t *testing.T) {
+	ctx := context.Background()
+	card := `{"id":"pm_1","object":"payment_method","type":"card","customer":"cus_1",
+		"card":{"brand":"visa","last4":"4242","exp_month":12,"exp_year":2029,"fingerprint":"fp_2"}}`
+	event := func(eventType stripego.EventType, previous map[string]interface{}) stripego.Event {
+		return stripego.Event{
+			Type:    eventType,
+			Created: 1791000000,
+			Data:    &stripego.EventData{Raw: []byte(card), PreviousAttributes: previous},
+		}
+	}
+
+	t.Run("should parse the card fields the account updater changed", func(t *testing.T) {
+		update, err := cardupdates.ParseUpdate(event(stripego.EventTypePaymentMethodAutomaticallyUpdated, map[string]interface{}{
+			"card": map[string]interface{}{"exp_year": 2025, "last4": "1111", "fingerprint": "fp_1"},
+		}))
+		require.NoError(t, err)
+		require.NotNil(t, update)
+		assert.Equal(t, cardupdates.SourceAccountUpdater, update.Source)
+		assert.Equal(t, "cus_1", update.CustomerID)
+		assert.Equal(t, []string{"last4", "exp_year", "fingerprint"}, update.Changes)
+		assert.Equal(t, 2025, update.Previous.ExpYear)
+		assert.Equal(t, 12, update.Previous.ExpMonth, "unchanged fields are carried over")
+		assert.Equal(t, 2029, update.Card.ExpYear)
+	})
+
+	t.Run("should ignore updates that changed no card details", func(t *testing.T) {
+		update, err := cardupdates.ParseUpdate(event(stripego.EventTypePaymentMethodUpdated, map[string]interface{}{
+			"billing_details": map[string]interface{}{"name": "Jane"},
+		}))
+		require.NoError(t, err)
+		assert.Nil(t, update)
+
+		update, err = cardupdates.ParseUpdate(event(stripego.EventTypePaymentMethodUpdated, map[string]interface{}{
+			"card": map[string]interface{}{"exp_month": 12},
+		}))
+		require.NoError(t, err)
+		assert.Nil(t, update)
+	})
+
+	t.Run("should refresh the vault fingerprint, announce the update and retry billing", func(t *testing.T) {
+		retrier := &MockCardUpdateRetrier{retried: 1}
+		tokens := &MockCardUpdateTokens{}
+		publisher := &MockEventPublisher{}
+		source, err := events.NewSource("/payments")
+		require.NoError(t, err)
+		service := cardupdates.NewService(retrier, tokens, events.NewEmitter(source, publisher))
+
+		update, err := cardupdates.ParseUpdate(event(stripego.EventTypePaymentMethodAutomaticallyUpdated, map[string]interface{}{
+			"card": map[string]interface{}{"exp_year": 2025, "fingerprint": "fp_1"},
+		}))
+		require.NoError(t, err)
+		require.NoError(t, service.Apply(ctx, update))
+
+		assert.Equal(t, []string{"stripe/pm_1/fp_2"}, tokens.updated)
+		require.Len(t, publisher.events, 1)
+		assert.Equal(t, cardupdates.EventPaymentMethodUpdated, publisher.events[0].Type)
+		assert.Equal(t, "pm_1", publisher.events[0].Subject)
+		assert.Equal(t, []string{"cus_1"}, retrier.customers)
+
+		retrier.err = errors.New("provider unavailable")
+		assert.NoError(t, service.Apply(ctx, update), "retries also run on schedule")
+	})
+}
+
+// MockCardUpdateRetrier records the customers whose payments were retried
+type MockCardUpdateRetrier struct {
+	customers []string
+	retried   int
+	err       error
+}
+
+func (m *MockCardUpdateRetrier) RetryCustomer(ctx context.Context, customerID string) (int, error) {
+	m.customers = append(m.customers, customerID)
+	return m.retried, m.err
+}
+
+// MockCardUpdateTokens records vault fingerprint updates
+type MockCardUpdateTokens struct {
+	updated []string
+}
+
+func (m *MockCardUpdateTokens) UpdateFingerprint(ctx context.Context, provider, providerToken, fingerprint string) error {
+	m.updated = append(m.updated, provider+"/"+providerToken+"/"+fingerprint)
+	return nil
+}
@@ -0,0 +1,152 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"apis/payments/services/chargesearch"
+	"apis/payments/services/stripe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestChargeSearch tests searching a tenant's charges with filters and
+// exporting them as CSV or JSON
+func TestChargeSearch(t *testing.T) {
+	ctx := context.Background()
+	amount := func(value int64) *int64 { return &value }
+
+	t.Run("should normalize filters before searching", func(t *testing.T) {
+		store := &MockChargeSearchStore{}
+		service := chargesearch.NewService(store)
+
+		_, err := service.Search(ctx, chargesearch.Filter{
+			TenantID:  "acme",
+			Currency:  " USD ",
+			CardBrand: "Visa",
+			CardLast4: "4242",
+			MinAmount: amount(100),
+			MaxAmount: amount(100),
+			Limit:     11,
+		})
+		require.NoError(t, err)
+
+		require.Len(t, store.filters, 1)
+		assert.Equal(t, "usd", store.filters[0].Currency)
+		assert.Equal(t, "visa", store.filters[0].CardBrand)
+		assert.Equal(t, 11, store.filters[0].Limit)
+	})
+
+	t.Run("should reject invalid ranges and card digits", func(t *testing.T) {
+		store := &MockChargeSearchStore{}
+		service := chargesearch.NewService(store)
+		after := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+
+		for _, tc := range []struct {
+			filter chargesearch.Filter
+			err    error
+		}{
+			{chargesearch.Filter{MinAmount: amount(500), MaxAmount: amount(100)}, chargesearch.ErrInvalidAmountRange},
+			{chargesearch.Filter{MinAmount: amount(-1)}, chargesearch.ErrInvalidAmountRange},
+			{chargesearch.Filter{CreatedAfter: &after, CreatedBefore: &after}, chargesearch.ErrInvalidCreatedRange},
+			{chargesearch.Filter{CardLast4: "42a2"}, chargesearch.ErrInvalidCardLast4},
+		} {
+			_, err := service.Search(ctx, tc.filter)
+			assert.ErrorIs(t, err, tc.err)
+		}
+		assert.Empty(t, store.filters)
+	})
+
+	t.Run("should export every page as CSV", func(t *testing.T) {
+		store := &MockChargeSearchStore{charges: mockSearchCharges(chargesearch.ExportPageSize + 1)}
+		store.charges[0].Description = "=HYPERLINK(\"http://example.com\")"
+		store.charges[0].Metadata = map[string]string{"order": "1001"}
+		var out bytes.Buffer
+
+		err := chargesearch.NewService(store).Export(ctx, chargesearch.Filter{TenantID: "acme", StartingAfter: "ch_x", Limit: 5}, chargesearch.FormatCSV, &out)
+		require.NoError(t, err)
+
+		records, err := csv.NewReader(&out).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, chargesearch.ExportPageSize+2)
+		assert.Equal(t, "id", records[0][0])
+		assert.Equal(t, []string{"ch_0", "2026-03-02T10:00:00Z", "1000", "0", "usd", "succeeded"}, records[1][:6])
+		assert.Equal(t, "'=HYPERLINK(\"http://example.com\")", records[1][12], "formulas are escaped")
+		assert.Equal(t, `{"order":"1001"}`, records[1][13])
+
+		require.Len(t, store.filters, 2)
+		assert.Equal(t, "", store.filters[0].StartingAfter, "exports start at the newest charge")
+		assert.Equal(t, fmt.Sprintf("ch_%d", chargesearch.ExportPageSize-1), store.filters[1].StartingAfter)
+	})
+
+	t.Run("should export a JSON array", func(t *testing.T) {
+		for _, count := range []int{0, 3} {
+			store := &MockChargeSearchStore{charges: mockSearchCharges(count)}
+			var out bytes.Buffer
+
+			require.NoError(t, chargesearch.NewService(store).Export(ctx, chargesearch.Filter{TenantID: "acme"}, chargesearch.FormatJSON, &out))
+
+			var charges []*stripe.Charge
+			require.NoError(t, json.Unmarshal(out.Bytes(), &charges), out.String())
+			assert.Len(t, charges, count)
+		}
+	})
+
+	t.Run("should reject unsupported formats and store failures", func(t *testing.T) {
+		var out bytes.Buffer
+		service := chargesearch.NewService(&MockChargeSearchStore{err: errors.New("connection refused")})
+
+		assert.ErrorIs(t, service.Export(ctx, chargesearch.Filter{}, "xlsx", &out), chargesearch.ErrUnsupportedFormat)
+		assert.Empty(t, out.String())
+		assert.ErrorContains(t, service.Export(ctx, chargesearch.Filter{}, chargesearch.FormatCSV, &out), "connection refused")
+	})
+}
+
+// mockSearchCharges returns count charges, newest first
+func mockSearchCharges(count int) []*stripe.Charge {
+	created := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	charges := make([]*stripe.Charge, count)
+	for i := range charges {
+		charges[i] = &stripe.Charge{
+			ID:       fmt.Sprintf("ch_%d", i),
+			Amount:   1000,
+			Currency: "usd",
+			Status:   "succeeded",
+			Created:  created.Add(-time.Duration(i) * time.Minute).Unix(),
+		}
+	}
+	return charges
+}
+
+// MockChargeSearchStore pages through charges held newest first, recording
+// the filter of each search
+type MockChargeSearchStore struct {
+	charges []*stripe.Charge
+	err     error
+	filters []chargesearch.Filter
+}
+
+func (m *MockChargeSearchStore) SearchCharges(ctx context.Context, filter chargesearch.Filter) ([]*stripe.Charge, error) {
+	m.filters = append(m.filters, filter)
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	start := 0
+	if filter.StartingAfter != "" {
+		for i, charge := range m.charges {
+			if charge.ID == filter.StartingAfter {
+				start = i + 1
+			}
+		}
+	}
+	end := min(start+filter.Limit, len(m.charges))
+	return m.charges[start:end], nil
+}
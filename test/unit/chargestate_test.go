@@ -0,0 +1,218 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"apis/payments/services/chargestate"
+	"apis/payments/services/events"
+	"apis/payments/services/stripe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestChargeStateMachine tests validating and recording charge state transitions
+func TestChargeStateMachine(t *testing.T) {
+	setup := func() (*chargestate.Service, *MockChargeTransitionStore, *MockEventPublisher) {
+		store := NewMockChargeTransitionStore()
+		publisher := &MockEventPublisher{}
+		source, err := events.NewSource("/payments")
+		require.NoError(t, err)
+		return chargestate.NewService(store, events.NewEmitter(source, publisher)), store, publisher
+	}
+
+	states := func(transitions []*chargestate.Transition) []string {
+		var result []string
+		for _, transition := range transitions {
+			result = append(result, transition.To)
+		}
+		return result
+	}
+
+	t.Run("should derive states from provider charges", func(t *testing.T) {
+		assert.Equal(t, chargestate.StateFailed, chargestate.Derive(&stripe.Charge{Status: "failed"}))
+		assert.Equal(t, chargestate.StateAuthorized, chargestate.Derive(&stripe.Charge{Status: "succeeded", Amount: 1000}))
+		assert.Equal(t, chargestate.StateVoided, chargestate.Derive(&stripe.Charge{Status: "succeeded", Amount: 1000, Refunded: true}))
+		assert.Equal(t, chargestate.StateCaptured, chargestate.Derive(&stripe.Charge{Status: "succeeded", Amount: 1000, Captured: true}))
+		assert.Equal(t, chargestate.StatePartiallyRefunded, chargestate.Derive(&stripe.Charge{Status: "succeeded", Amount: 1000, AmountRefunded: 400, Captured: true}))
+		assert.Equal(t, chargestate.StateRefunded, chargestate.Derive(&stripe.Charge{Status: "succeeded", Amount: 1000, AmountRefunded: 1000, Captured: true}))
+	})
+
+	t.Run("should record legal transitions and emit events", func(t *testing.T) {
+		service, store, publisher := setup()
+
+		_, err := service.Transition(context.Background(), "ch_1", chargestate.StateAuthorized, chargestate.SourceAPI, "")
+		require.NoError(t, err)
+		transition, err := service.Transition(context.Background(), "ch_1", chargestate.StateCaptured, chargestate.SourceAPI, "")
+		require.NoError(t, err)
+
+		assert.Equal(t, chargestate.StateAuthorized, transition.From)
+		assert.Equal(t, 3, transition.Sequence)
+		assert.Equal(t, []string{chargestate.StateCreated, chargestate.StateAuthorized, chargestate.StateCaptured}, states(store.transitions["ch_1"]))
+		require.Len(t, publisher.events, 3)
+		assert.Equal(t, chargestate.EventTransitioned, publisher.events[2].Type)
+	})
+
+	t.Run("should reject illegal transitions without recording them", func(t *testing.T) {
+		service, store, _ := setup()
+
+		_, err := service.Transition(context.Background(), "ch_1", chargestate.StateRefunded, chargestate.SourceAPI, "")
+		assert.ErrorIs(t, err, chargestate.ErrIllegalTransition)
+		assert.Empty(t, store.transitions["ch_1"])
+
+		_, err = service.Transition(context.Background(), "ch_1", chargestate.StateFailed, chargestate.SourceAPI, "")
+		require.NoError(t, err)
+		_, err = service.Transition(context.Background(), "ch_1", chargestate.StateCaptured, chargestate.SourceAPI, "")
+		assert.ErrorIs(t, err, chargestate.ErrIllegalTransition)
+
+		_, err = service.Transition(context.Background(), "ch_1", "settled", chargestate.SourceAPI, "")
+		assert.ErrorIs(t, err, chargestate.ErrUnknownState)
+	})
+
+	t.Run("should ignore transitions to the current state", func(t *testing.T) {
+		service, store, _ := setup()
+		charge := &stripe.Charge{ID: "ch_1", Status: "succeeded", Amount: 1000, Captured: true}
+
+		_, err := service.Apply(context.Background(), charge, chargestate.SourceWebhook, "evt_1")
+		require.NoError(t, err)
+		_, err = service.Apply(context.Background(), charge, chargestate.SourceWebhook, "evt_1")
+		require.NoError(t, err)
+
+		assert.Len(t, store.transitions["ch_1"], 2)
+	})
+
+	t.Run("should fill in a missed capture from a provider snapshot", func(t *testing.T) {
+		service, store, _ := setup()
+		_, err := service.Transition(context.Background(), "ch_1", chargestate.StateAuthorized, chargestate.SourceAPI, "")
+		require.NoError(t, err)
+
+		refunded := &stripe.Charge{ID: "ch_1", Status: "succeeded", Amount: 1000, AmountRefunded: 1000, Captured: true, Refunded: true}
+		_, err = service.Apply(context.Background(), refunded, chargestate.SourceWebhook, "evt_refunded")
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{chargestate.StateCreated, chargestate.StateAuthorized, chargestate.StateCaptured, chargestate.StateRefunded}, states(store.transitions["ch_1"]))
+	})
+
+	t.Run("should keep disputed charges disputed until the dispute is won", func(t *testing.T) {
+		service, store, _ := setup()
+		charge := &stripe.Charge{ID: "ch_1", Status: "succeeded", Amount: 1000, Captured: true}
+		_, err := service.Apply(context.Background(), charge, chargestate.SourceAPI, "")
+		require.NoError(t, err)
+
+		_, err = service.Dispute(context.Background(), "ch_1", chargestate.SourceWebhook, "evt_dispute")
+		require.NoError(t, err)
+
+		charge.Disputed = true
+		_, err = service.Apply(context.Background(), charge, chargestate.SourceWebhook, "evt_updated")
+		require.NoError(t, err)
+		state, err := service.State(context.Background(), "ch_1")
+		require.NoError(t, err)
+		assert.Equal(t, chargestate.StateDisputed, state)
+
+		_, err = service.CloseDispute(context.Background(), charge, "lost", chargestate.SourceWebhook, "evt_lost")
+		require.NoError(t, err)
+		assert.Equal(t, chargestate.StateDisputed, store.latest("ch_1").To)
+
+		_, err = service.CloseDispute(context.Background(), charge, "won", chargestate.SourceWebhook, "evt_won")
+		require.NoError(t, err)
+		assert.Equal(t, chargestate.StateCaptured, store.latest("ch_1").To)
+	})
+
+	t.Run("should only allow refunds of captured charges", func(t *testing.T) {
+		service, _, _ := setup()
+
+		assert.NoError(t, service.Allows(context.Background(), "ch_unknown", chargestate.StateRefunded))
+
+		_, err := service.Transition(context.Background(), "ch_1", chargestate.StateAuthorized, chargestate.SourceAPI, "")
+		require.NoError(t, err)
+		err = service.Allows(context.Background(), "ch_1", chargestate.StatePartiallyRefunded, chargestate.StateRefunded)
+		assert.ErrorIs(t, err, chargestate.ErrIllegalTransition)
+
+		_, err = service.Transition(context.Background(), "ch_1", chargestate.StateCaptured, chargestate.SourceAPI, "")
+		require.NoError(t, err)
+		assert.NoError(t, service.Allows(context.Background(), "ch_1", chargestate.StatePartiallyRefunded, chargestate.StateRefunded))
+	})
+
+	t.Run("should reject illegal manual transitions", func(t *testing.T) {
+		service, store, _ := setup()
+		_, err := service.Transition(context.Background(), "ch_1", chargestate.StateCaptured, chargestate.SourceAPI, "")
+		require.NoError(t, err)
+
+		_, err = service.Transition(context.Background(), "ch_1", chargestate.StateVoided, chargestate.SourceManual, "")
+		assert.ErrorIs(t, err, chargestate.ErrIllegalTransition)
+		_, err = service.Transition(context.Background(), "ch_1", "settled", chargestate.SourceManual, "")
+		assert.ErrorIs(t, err, chargestate.ErrUnknownState)
+
+		transition, err := service.Transition(context.Background(), "ch_1", chargestate.StateRefunded, chargestate.SourceManual, "")
+		require.NoError(t, err)
+		assert.Equal(t, chargestate.SourceManual, transition.Source)
+		assert.Equal(t, chargestate.StateRefunded, store.latest("ch_1").To)
+	})
+
+	t.Run("should report when each state was last entered", func(t *testing.T) {
+		start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+		entered := chargestate.EnteredAt([]*chargestate.Transition{
+			{To: chargestate.StateCreated, CreatedAt: start},
+			{To: chargestate.StateCaptured, CreatedAt: start.Add(time.Minute)},
+			{To: chargestate.StateDisputed, CreatedAt: start.Add(time.Hour)},
+			{To: chargestate.StateCaptured, CreatedAt: start.Add(48 * time.Hour)},
+		})
+
+		assert.Equal(t, start, entered[chargestate.StateCreated])
+		assert.Equal(t, start.Add(48*time.Hour), entered[chargestate.StateCaptured])
+		assert.Equal(t, start.Add(time.Hour), entered[chargestate.StateDisputed])
+		assert.NotContains(t, entered, chargestate.StateRefunded)
+	})
+
+	t.Run("should report concurrent transitions", func(t *testing.T) {
+		service, store, _ := setup()
+		_, err := service.Transition(context.Background(), "ch_1", chargestate.StateAuthorized, chargestate.SourceAPI, "")
+		require.NoError(t, err)
+
+		store.conflict = true
+		_, err = service.Transition(context.Background(), "ch_1", chargestate.StateCaptured, chargestate.SourceAPI, "")
+		assert.ErrorIs(t, err, chargestate.ErrConcurrentTransition)
+	})
+}
+
+// MockChargeTransitionStore keeps charge transition logs in memory
+type MockChargeTransitionStore struct {
+	transitions map[string][]*chargestate.Transition
+	conflict    bool
+}
+
+// NewMockChargeTransitionStore creates an empty transition store
+func NewMockChargeTransitionStore() *MockChargeTransitionStore {
+	return &MockChargeTransitionStore{transitions: make(map[string][]*chargestate.Transition)}
+}
+
+func (m *MockChargeTransitionStore) latest(chargeID string) *chargestate.Transition {
+	transitions := m.transitions[chargeID]
+	if len(transitions) == 0 {
+		return nil
+	}
+	return transitions[len(transitions)-1]
+}
+
+func (m *MockChargeTransitionStore) AppendChargeTransition(ctx context.Context, transition *chargestate.Transition) (*chargestate.Transition, error) {
+	if latest := m.latest(transition.ChargeID); m.conflict || (latest != nil && latest.Sequence >= transition.Sequence) {
+		return nil, sql.ErrNoRows
+	}
+	m.transitions[transition.ChargeID] = append(m.transitions[transition.ChargeID], transition)
+	return transition, nil
+}
+
+func (m *MockChargeTransitionStore) GetLatestChargeTransition(ctx context.Context, chargeID string) (*chargestate.Transition, error) {
+	latest := m.latest(chargeID)
+	if latest == nil {
+		return nil, sql.ErrNoRows
+	}
+	return latest, nil
+}
+
+func (m *MockChargeTransitionStore) ListChargeTransitions(ctx context.Context, chargeID string) ([]*chargestate.Transition, error) {
+	return m.transitions[chargeID], nil
+}
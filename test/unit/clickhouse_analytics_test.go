@@ -0,0 +1,304 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"apis/payments/db/clickhouse"
+	"apis/payments/services/batching"
+	"apis/payments/services/stripe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// TestClickHouseAnalytics tests migrating the analytics tables and logging
+// events to them in batches
+func TestClickHouseAnalytics(t *testing.T) {
+	ctx := context.Background()
+	table := &clickhouse.Table{Name: "things", Columns: []string{"id", "amount"}}
+	other := &clickhouse.Table{Name: "others", Columns: []string{"id"}}
+
+	t.Run("should embed migrations in version order", func(t *testing.T) {
+		migrations, err := clickhouse.Migrations()
+		require.NoError(t, err)
+		require.NotEmpty(t, migrations)
+
+		for i, migration := range migrations {
+			assert.Equal(t, uint32(i+1), migration.Version, migration.Name)
+			assert.NotEmpty(t, migration.Statements, migration.Name)
+			for _, statement := range migration.Statements {
+				assert.NotContains(t, statement, "--", migration.Name)
+				assert.False(t, strings.HasSuffix(statement, ";"), migration.Name)
+			}
+		}
+	})
+
+	t.Run("should apply and record every migration on a new database", func(t *testing.T) {
+		conn := &MockClickHouseMigrator{}
+		require.NoError(t, clickhouse.Migrate(ctx, conn))
+
+		migrations, err := clickhouse.Migrations()
+		require.NoError(t, err)
+		assert.Len(t, conn.recorded(), len(migrations))
+		assert.Contains(t, conn.executed[0], "CREATE TABLE IF NOT EXISTS schema_migrations")
+		for _, name := range []string{"payment_events", "charge_attempts", "refund_events", "dispute_events", "subscription_events", "payout_events"} {
+			assert.True(t, conn.ran("CREATE TABLE IF NOT EXISTS "+name), name)
+		}
+	})
+
+	t.Run("should only apply migrations not yet recorded", func(t *testing.T) {
+		conn := &MockClickHouseMigrator{applied: []uint32{1, 2}}
+		require.NoError(t, clickhouse.Migrate(ctx, conn))
+
+		assert.False(t, conn.ran("CREATE TABLE IF NOT EXISTS payment_events"))
+		assert.True(t, conn.ran("ALTER TABLE payment_events"))
+
+		migrations, err := clickhouse.Migrations()
+		require.NoError(t, err)
+		var pending []uint32
+		for _, migration := range migrations[2:] {
+			pending = append(pending, migration.Version)
+		}
+		assert.Equal(t, pending, conn.recorded())
+	})
+
+	t.Run("should stop without recording a failed migration", func(t *testing.T) {
+		conn := &MockClickHouseMigrator{fail: "ALTER TABLE payment_events"}
+		err := clickhouse.Migrate(ctx, conn)
+		assert.ErrorContains(t, err, "003_add_payment_event_details")
+
+		assert.Equal(t, []uint32{1, 2}, conn.recorded())
+		assert.False(t, conn.ran("CREATE TABLE IF NOT EXISTS refund_events"))
+	})
+
+	t.Run("should insert a table's rows once it has a full batch", func(t *testing.T) {
+		inserts := &MockClickHouseInserts{}
+		writer := clickhouse.NewWriter(inserts.insert, &clickhouse.Config{Batches: fixedBatches(2), FlushInterval: time.Hour, QueueSize: 10})
+		stop := writer.Start()
+
+		require.NoError(t, writer.Write(table, "a", int64(1)))
+		require.NoError(t, writer.Write(other, "x"))
+		require.NoError(t, writer.Write(table, "b", int64(2)))
+
+		assert.Eventually(t, func() bool { return len(inserts.batches()) == 1 }, time.Second, time.Millisecond)
+		assert.Equal(t, "things: a b", inserts.batches()[0])
+
+		stop()
+		assert.Equal(t, []string{"things: a b", "others: x"}, inserts.batches(), "stopping inserts queued rows")
+	})
+
+	t.Run("should insert partial batches at every flush interval", func(t *testing.T) {
+		inserts := &MockClickHouseInserts{}
+		writer := clickhouse.NewWriter(inserts.insert, &clickhouse.Config{Batches: fixedBatches(100), FlushInterval: 5 * time.Millisecond, QueueSize: 10})
+		stop := writer.Start()
+		defer stop()
+
+		require.NoError(t, writer.Write(table, "a", int64(1)))
+		assert.Eventually(t, func() bool { return len(inserts.batches()) == 1 }, time.Second, time.Millisecond)
+	})
+
+	t.Run("should drop rows rather than block when the queue is full", func(t *testing.T) {
+		inserts := &MockClickHouseInserts{}
+		writer := clickhouse.NewWriter(inserts.insert, &clickhouse.Config{Batches: fixedBatches(100), FlushInterval: time.Hour, QueueSize: 1})
+
+		require.NoError(t, writer.Write(table, "a", int64(1)))
+		assert.ErrorIs(t, writer.Write(table, "b", int64(2)), clickhouse.ErrQueueFull)
+		assert.ErrorContains(t, writer.Write(table, "c"), "things takes 2 columns, got 1")
+		assert.Equal(t, int64(1), writer.Dropped())
+
+		writer.Start()()
+		assert.Equal(t, []string{"things: a"}, inserts.batches())
+	})
+
+	t.Run("should count rows that fail to insert as dropped", func(t *testing.T) {
+		inserts := &MockClickHouseInserts{err: errors.New("clickhouse unavailable")}
+		writer := clickhouse.NewWriter(inserts.insert, &clickhouse.Config{Batches: fixedBatches(100), FlushInterval: time.Hour, QueueSize: 10})
+		stop := writer.Start()
+
+		require.NoError(t, writer.Write(table, "a", int64(1)))
+		require.NoError(t, writer.Write(table, "b", int64(2)))
+		stop()
+		assert.Equal(t, int64(2), writer.Dropped())
+	})
+
+	t.Run("should shrink batches when inserts fail and grow them when they succeed", func(t *testing.T) {
+		inserts := &MockClickHouseInserts{err: errors.New("too many parts")}
+		limits := &batching.Limits{MinSize: 1, MaxSize: 8, InitialSize: 4, Step: 2, TargetLatency: time.Second, Backoff: 0.5}
+		writer := clickhouse.NewWriter(inserts.insert, &clickhouse.Config{Batches: limits, FlushInterval: time.Hour, QueueSize: 10})
+		stop := writer.Start()
+		defer stop()
+
+		for _, id := range []string{"a", "b", "c", "d"} {
+			require.NoError(t, writer.Write(table, id, int64(1)))
+		}
+		assert.Eventually(t, func() bool { return writer.BatchStats().Batches == 1 }, time.Second, time.Millisecond)
+		assert.Equal(t, 2, writer.BatchStats().Size)
+
+		inserts.mu.Lock()
+		inserts.err = nil
+		inserts.mu.Unlock()
+		require.NoError(t, writer.Write(table, "e", int64(1)))
+		require.NoError(t, writer.Write(table, "f", int64(1)))
+		assert.Eventually(t, func() bool { return len(inserts.batches()) == 1 }, time.Second, time.Millisecond)
+		assert.Equal(t, "things: e f", inserts.batches()[0])
+		assert.Eventually(t, func() bool { return writer.BatchStats().Size == 4 }, time.Second, time.Millisecond)
+	})
+
+	t.Run("should log provider events of every type", func(t *testing.T) {
+		inserts := &MockClickHouseInserts{}
+		writer := clickhouse.NewWriter(inserts.insert, &clickhouse.Config{Batches: fixedBatches(100), FlushInterval: time.Hour, QueueSize: 100})
+		webhooks := stripe.NewWebhookService("whsec_test")
+		clickhouse.NewAnalyticsService(nil, writer).RegisterWebhookHandlers(webhooks)
+
+		subscription := `{"id": "sub_1", "customer": {"id": "cus_1"}, "status": "active", "metadata": {"tenant_id": "acme"},
+			"items": {"data": [{"quantity": 2, "price": {"id": "price_1", "unit_amount": 12000, "currency": "usd", "recurring": {"interval": "year"}}}]}}`
+		events := map[stripego.EventType]string{
+			stripego.EventTypeChargeSucceeded:             `{"id": "ch_1", "amount": 1000, "currency": "usd", "status": "succeeded"}`,
+			stripego.EventTypeCustomerCreated:             `{"id": "cus_1", "email": "ada@example.com"}`,
+			stripego.EventTypePaymentMethodAttached:       `{"id": "pm_1", "type": "card", "card": {"last4": "4242", "exp_month": 12, "exp_year": 2030}}`,
+			stripego.EventTypeRefundCreated:               `{"id": "re_1", "charge": {"id": "ch_1"}, "amount": 500, "status": "succeeded"}`,
+			stripego.EventTypeChargeDisputeCreated:        `{"id": "dp_1", "charge": {"id": "ch_1"}, "amount": 1000, "status": "needs_response"}`,
+			stripego.EventTypeCustomerSubscriptionCreated: subscription,
+			stripego.EventTypePayoutPaid:                  `{"id": "po_1", "amount": 9000, "currency": "usd", "status": "paid"}`,
+		}
+		for eventType, raw := range events {
+			event := stripego.Event{ID: "evt_" + string(eventType), Type: eventType, Data: &stripego.EventData{Raw: []byte(raw)}}
+			require.NoError(t, webhooks.Dispatch(ctx, event), eventType)
+		}
+		writer.Start()()
+
+		assert.ElementsMatch(t, []string{
+			"payment_events", "customer_events", "payment_method_events", "refund_events",
+			"dispute_events", "subscription_events", "payout_events",
+		}, inserts.tables())
+		assert.Equal(t, "refund_created", inserts.row("refund_events")[1])
+		assert.Equal(t, "ch_1", inserts.row("refund_events")[3])
+		assert.Equal(t, "default", inserts.row("payment_events")[2])
+
+		row := inserts.row("subscription_events")
+		assert.Equal(t, "customer_subscription_created", row[1])
+		assert.Equal(t, "acme", row[2])
+		assert.Equal(t, int64(2000), row[8], "MRR of 2 units at 12000 a year")
+		assert.Equal(t, uint8(12), inserts.row("payment_method_events")[7])
+	})
+}
+
+// MockClickHouseMigrator records the statements run by migrations,
+// starting with the versions in applied already recorded
+type MockClickHouseMigrator struct {
+	applied  []uint32
+	fail     string
+	executed []string
+	versions []uint32
+}
+
+func (m *MockClickHouseMigrator) Exec(ctx context.Context, query string, args ...any) error {
+	if m.fail != "" && strings.Contains(query, m.fail) {
+		return errors.New("syntax error")
+	}
+	m.executed = append(m.executed, query)
+	if strings.Contains(query, "INSERT INTO schema_migrations") {
+		m.versions = append(m.versions, args[0].(uint32))
+	}
+	return nil
+}
+
+func (m *MockClickHouseMigrator) Select(ctx context.Context, dest any, query string, args ...any) error {
+	rows := make([]map[string]uint32, len(m.applied))
+	for i, version := range m.applied {
+		rows[i] = map[string]uint32{"Version": version}
+	}
+	data, err := json.Marshal(rows)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dest)
+}
+
+func (m *MockClickHouseMigrator) recorded() []uint32 {
+	return m.versions
+}
+
+func (m *MockClickHouseMigrator) ran(prefix string) bool {
+	for _, query := range m.executed {
+		if strings.HasPrefix(strings.TrimSpace(query), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// MockClickHouseInserts records the batches inserted by a writer
+type MockClickHouseInserts struct {
+	mu      sync.Mutex
+	err     error
+	inserts []MockClickHouseInsert
+}
+
+// MockClickHouseInsert is a batch of rows inserted into a table
+type MockClickHouseInsert struct {
+	table string
+	rows  [][]any
+}
+
+func (m *MockClickHouseInserts) insert(ctx context.Context, table *clickhouse.Table, rows [][]any) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.err != nil {
+		return m.err
+	}
+	m.inserts = append(m.inserts, MockClickHouseInsert{table: table.Name, rows: rows})
+	return nil
+}
+
+// batches describes each batch as its table and the first value of its rows
+func (m *MockClickHouseInserts) batches() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	batches := []string{}
+	for _, insert := range m.inserts {
+		ids := make([]string, len(insert.rows))
+		for i, row := range insert.rows {
+			ids[i] = row[0].(string)
+		}
+		batches = append(batches, insert.table+": "+strings.Join(ids, " "))
+	}
+	return batches
+}
+
+func (m *MockClickHouseInserts) tables() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var tables []string
+	for _, insert := range m.inserts {
+		tables = append(tables, insert.table)
+	}
+	return tables
+}
+
+func (m *MockClickHouseInserts) row(table string) []any {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, insert := range m.inserts {
+		if insert.table == table {
+			return insert.rows[0]
+		}
+	}
+	return nil
+}
+
+// fixedBatches returns limits that keep batches at one size
+func fixedBatches(size int) *batching.Limits {
+	return &batching.Limits{MinSize: size, MaxSize: size, InitialSize: size, TargetLatency: time.Hour, Backoff: 0.5}
+}
@@ -0,0 +1,210 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"apis/payments/db/clickhouse"
+	"apis/payments/services/batching"
+	"apis/payments/services/events"
+	"apis/payments/services/kafka"
+	"apis/payments/services/stripe"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// TestClickHouseIngest tests ingesting the events relayed to Kafka into the
+// analytics tables in batches, storing each event once
+func TestClickHouseIngest(t *testing.T) {
+	ctx := context.Background()
+	occurred := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+
+	message := func(id, eventType, data string) *kafka.Message {
+		value, err := json.Marshal(&events.Event{
+			SpecVersion: events.SpecVersion,
+			ID:          id,
+			Source:      "/payments/charges",
+			Type:        eventType,
+			Time:        occurred,
+			Data:        json.RawMessage(data),
+		})
+		require.NoError(t, err)
+		return &kafka.Message{Topic: "payment-events", Value: value}
+	}
+	charge := `{"id": "ch_1", "amount": 1000, "currency": "usd", "status": "succeeded", "metadata": {"tenant_id": "acme"}}`
+
+	t.Run("should store relayed events as the rows webhooks log", func(t *testing.T) {
+		inserts := &MockClickHouseInserts{}
+		ingester := clickhouse.NewIngester(&MockClickHouseEventStore{}, inserts.insert)
+
+		err := ingester.HandleBatch(ctx, []*kafka.Message{
+			message("evt_1", "payments.charge.succeeded", charge),
+			message("evt_2", "payments.refund.created", `{"id": "re_1", "charge_id": "ch_1", "amount": 500, "status": "succeeded"}`),
+			message("evt_3", "payments.dispute.created", `{"id": "dp_1", "charge_id": "ch_1", "amount": 1000}`),
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"payment_events: evt_1", "refund_events: evt_2", "dispute_events: evt_3"}, inserts.batches())
+		row := inserts.row("payment_events")
+		assert.Equal(t, "charge_succeeded", row[1])
+		assert.Equal(t, "acme", row[2])
+		assert.Equal(t, occurred, row[len(row)-1], "rows are timed by the event")
+		assert.Equal(t, "refund_created", inserts.row("refund_events")[1])
+		assert.Equal(t, "charge_dispute_created", inserts.row("dispute_events")[1])
+	})
+
+	t.Run("should skip events already stored or repeated in the batch", func(t *testing.T) {
+		inserts := &MockClickHouseInserts{}
+		store := &MockClickHouseEventStore{stored: []string{"evt_1"}}
+		ingester := clickhouse.NewIngester(store, inserts.insert)
+
+		err := ingester.HandleBatch(ctx, []*kafka.Message{
+			message("evt_1", "payments.charge.succeeded", charge),
+			message("evt_2", "payments.charge.refunded", charge),
+			message("evt_2", "payments.charge.refunded", charge),
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"payment_events: evt_2"}, inserts.batches())
+		require.Len(t, store.queries, 1)
+		assert.Equal(t, []any{occurred, occurred, []string{"evt_1", "evt_2"}}, store.queries[0])
+	})
+
+	t.Run("should skip other events and messages that aren't events", func(t *testing.T) {
+		inserts := &MockClickHouseInserts{}
+		ingester := clickhouse.NewIngester(&MockClickHouseEventStore{}, inserts.insert)
+
+		err := ingester.HandleBatch(ctx, []*kafka.Message{
+			{Topic: "payment-events", Value: []byte("not json")},
+			message("evt_1", "payments.invoice.paid", `{"id": "in_1"}`),
+			message("evt_2", "payments.charge.flagged", `{"id": "rev_1"}`),
+			message("evt_3", "payments.charge.succeeded", `"malformed"`),
+		})
+		require.NoError(t, err)
+		assert.Empty(t, inserts.batches())
+	})
+
+	t.Run("should fail the batch when events can't be stored", func(t *testing.T) {
+		batch := []*kafka.Message{message("evt_1", "payments.charge.succeeded", charge)}
+
+		lookupFails := clickhouse.NewIngester(&MockClickHouseEventStore{err: errors.New("clickhouse unavailable")}, (&MockClickHouseInserts{}).insert)
+		assert.ErrorContains(t, lookupFails.HandleBatch(ctx, batch), "failed to look up stored payment_events")
+
+		insertFails := clickhouse.NewIngester(&MockClickHouseEventStore{}, (&MockClickHouseInserts{err: errors.New("too many parts")}).insert)
+		assert.ErrorContains(t, insertFails.HandleBatch(ctx, batch), "failed to insert 1 rows into payment_events")
+	})
+
+	t.Run("should only log events that aren't relayed from webhooks", func(t *testing.T) {
+		inserts := &MockClickHouseInserts{}
+		writer := clickhouse.NewWriter(inserts.insert, &clickhouse.Config{Batches: fixedBatches(100), FlushInterval: time.Hour, QueueSize: 100})
+		analytics := clickhouse.NewAnalyticsService(nil, writer)
+		analytics.SkipRelayedEvents()
+		webhooks := stripe.NewWebhookService("whsec_test")
+		analytics.RegisterWebhookHandlers(webhooks)
+
+		for eventType, raw := range map[stripego.EventType]string{
+			stripego.EventTypeChargeSucceeded: charge,
+			stripego.EventTypePayoutPaid:      `{"id": "po_1", "amount": 9000, "currency": "usd", "status": "paid"}`,
+		} {
+			event := stripego.Event{ID: "evt_" + string(eventType), Type: eventType, Data: &stripego.EventData{Raw: []byte(raw)}}
+			require.NoError(t, webhooks.Dispatch(ctx, event), eventType)
+		}
+		writer.Start()()
+
+		assert.Equal(t, []string{"payout_events"}, inserts.tables())
+	})
+
+	consumerConfig := &kafka.Config{GroupID: "payments-analytics", Topics: []string{"payment-events"}, Backoff: time.Millisecond, MaxBackoff: time.Millisecond}
+	claim := func(offsets ...int64) *MockConsumerGroupClaim {
+		messages := make(chan *sarama.ConsumerMessage, len(offsets))
+		for _, offset := range offsets {
+			messages <- &sarama.ConsumerMessage{Topic: "payment-events", Offset: offset}
+		}
+		close(messages)
+		return &MockConsumerGroupClaim{messages: messages}
+	}
+
+	t.Run("should hand over full batches and mark each once handled", func(t *testing.T) {
+		handler := &MockKafkaBatchHandler{failures: 1}
+		consumer := kafka.NewBatchConsumerWithGroup(consumerConfig, nil, handler, batching.NewController(fixedBatches(2)), time.Hour)
+		session := NewMockConsumerGroupSession()
+
+		require.NoError(t, consumer.ConsumeClaim(session, claim(1, 2, 3)))
+		assert.Equal(t, [][]int64{{1, 2}, {1, 2}, {3}}, handler.batches, "the failed batch is retried")
+		assert.Equal(t, []int64{2, 3}, session.marked)
+	})
+
+	t.Run("should size batches by how earlier ones went", func(t *testing.T) {
+		handler := &MockKafkaBatchHandler{failures: 1}
+		batches := batching.NewController(&batching.Limits{MinSize: 1, MaxSize: 4, InitialSize: 2, Step: 1, TargetLatency: time.Second, Backoff: 0.5})
+		consumer := kafka.NewBatchConsumerWithGroup(consumerConfig, nil, handler, batches, time.Hour)
+		session := NewMockConsumerGroupSession()
+
+		require.NoError(t, consumer.ConsumeClaim(session, claim(1, 2, 3, 4, 5, 6)))
+		assert.Equal(t, [][]int64{{1, 2}, {1, 2}, {3, 4}, {5, 6}}, handler.batches, "the failure shrinks the size to 1, and each full batch grows it again")
+		assert.Equal(t, 3, batches.Size())
+	})
+
+	t.Run("should leave a batch unmarked when the session ends first", func(t *testing.T) {
+		handler := &MockKafkaBatchHandler{failures: -1}
+		consumer := kafka.NewBatchConsumerWithGroup(consumerConfig, nil, handler, batching.NewController(fixedBatches(1)), time.Hour)
+		session := NewMockConsumerGroupSession()
+		time.AfterFunc(10*time.Millisecond, session.cancel)
+
+		require.NoError(t, consumer.ConsumeClaim(session, claim(1)))
+		assert.NotEmpty(t, handler.batches)
+		assert.Empty(t, session.marked)
+	})
+}
+
+// MockClickHouseEventStore holds the IDs of stored events, recording the
+// arguments of each lookup
+type MockClickHouseEventStore struct {
+	stored  []string
+	err     error
+	queries [][]any
+}
+
+func (m *MockClickHouseEventStore) Select(ctx context.Context, dest any, query string, args ...any) error {
+	m.queries = append(m.queries, args)
+	if m.err != nil {
+		return m.err
+	}
+
+	rows := make([]map[string]string, len(m.stored))
+	for i, id := range m.stored {
+		rows[i] = map[string]string{"EventID": id}
+	}
+	data, err := json.Marshal(rows)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dest)
+}
+
+// MockKafkaBatchHandler records the offsets of each batch, failing the
+// first failures of them, or all of them when negative
+type MockKafkaBatchHandler struct {
+	failures int
+	batches  [][]int64
+}
+
+func (m *MockKafkaBatchHandler) HandleBatch(ctx context.Context, messages []*kafka.Message) error {
+	offsets := make([]int64, len(messages))
+	for i, message := range messages {
+		offsets[i] = message.Offset
+	}
+	m.batches = append(m.batches, offsets)
+
+	if m.failures != 0 {
+		m.failures--
+		return errors.New("clickhouse unavailable")
+	}
+	return nil
+}
@@ -0,0 +1,275 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"apis/payments/services/chargestate"
+	"apis/payments/services/commands"
+	"apis/payments/services/events"
+	"apis/payments/services/kafka"
+	"apis/payments/services/refundguard"
+	"apis/payments/services/stripe"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCommands tests executing commands consumed from Kafka, with retries
+// and dead-letter routing
+func TestCommands(t *testing.T) {
+	setup := func() (*commands.Service, *MockCommandRefunder, *MockCommandChargeStates, *MockEventPublisher) {
+		refunder := NewMockCommandRefunder()
+		states := &MockCommandChargeStates{}
+		publisher := &MockEventPublisher{}
+		source, err := events.NewSource("/payments")
+		require.NoError(t, err)
+		service := commands.NewService(refunder, states, &MockCommandCapturer{}, &MockCommandCanceller{}, events.NewEmitter(source, publisher))
+		return service, refunder, states, publisher
+	}
+
+	message := func(value string) *kafka.Message {
+		return &kafka.Message{Topic: "payment-commands", Value: []byte(value)}
+	}
+
+	consumerConfig := func() *kafka.Config {
+		return &kafka.Config{
+			GroupID:     "payments",
+			Topics:      []string{"payment-commands"},
+			DLQTopic:    "payment-commands.dlq",
+			MaxAttempts: 3,
+			Backoff:     time.Millisecond,
+			MaxBackoff:  time.Millisecond,
+		}
+	}
+
+	consume := func(consumer *kafka.Consumer, session *MockConsumerGroupSession, messages ...*sarama.ConsumerMessage) {
+		claim := make(chan *sarama.ConsumerMessage, len(messages))
+		for _, m := range messages {
+			claim <- m
+		}
+		close(claim)
+		require.NoError(t, consumer.ConsumeClaim(session, &MockConsumerGroupClaim{messages: claim}))
+	}
+
+	t.Run("should issue refunds keyed by the command ID and emit the outcome", func(t *testing.T) {
+		service, refunder, _, publisher := setup()
+
+		err := service.Handle(context.Background(), message(`{"id": "cmd_1", "type": "refund.create", "tenant_id": "acme", "requested_by": "orders", "data": {"charge_id": "ch_123", "amount": 500}}`))
+		require.NoError(t, err)
+
+		require.Len(t, refunder.requests, 1)
+		assert.Equal(t, "command-cmd_1", refunder.requests[0].IdempotencyKey)
+		assert.Equal(t, "cmd_1", refunder.requests[0].Metadata[commands.CommandMetadataKey])
+		assert.Equal(t, refundguard.Actor{TenantID: "acme", OperatorID: "orders"}, refunder.actors[0])
+
+		require.Len(t, publisher.events, 1)
+		assert.Equal(t, commands.EventSucceeded, publisher.events[0].Type)
+		assert.Equal(t, "cmd_1", publisher.events[0].Subject)
+	})
+
+	t.Run("should fail commands that can never succeed permanently", func(t *testing.T) {
+		service, refunder, states, publisher := setup()
+
+		for _, value := range []string{
+			`not json`,
+			`{"id": "cmd_1", "type": "charge.explode", "data": {}}`,
+			`{"type": "refund.create", "data": {"charge_id": "ch_123"}}`,
+			`{"id": "cmd_1", "type": "refund.create", "data": {"amount": 500}}`,
+			`{"id": "cmd_1", "type": "charge.capture"}`,
+		} {
+			err := service.Handle(context.Background(), message(value))
+			assert.ErrorIs(t, err, kafka.ErrPermanent, value)
+			assert.ErrorIs(t, err, commands.ErrInvalidCommand, value)
+		}
+		assert.Empty(t, refunder.requests)
+
+		states.err = chargestate.ErrIllegalTransition
+		err := service.Handle(context.Background(), message(`{"id": "cmd_2", "type": "refund.create", "data": {"charge_id": "ch_123"}}`))
+		assert.ErrorIs(t, err, kafka.ErrPermanent)
+		require.NotEmpty(t, publisher.events)
+		assert.Equal(t, commands.EventFailed, publisher.events[len(publisher.events)-1].Type)
+	})
+
+	t.Run("should leave provider failures to be retried", func(t *testing.T) {
+		service, refunder, _, publisher := setup()
+		refunder.err = errors.New("stripe unavailable")
+
+		err := service.Handle(context.Background(), message(`{"id": "cmd_1", "type": "refund.create", "data": {"charge_id": "ch_123"}}`))
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, kafka.ErrPermanent)
+		assert.Empty(t, publisher.events)
+	})
+
+	t.Run("should retry transient failures before marking the message", func(t *testing.T) {
+		producer := &MockSyncProducer{}
+		calls := 0
+		consumer := kafka.NewConsumerWithClients(consumerConfig(), nil, producer, kafka.HandlerFunc(func(ctx context.Context, message *kafka.Message) error {
+			calls++
+			if calls < 3 {
+				return errors.New("temporarily unavailable")
+			}
+			return nil
+		}))
+
+		session := NewMockConsumerGroupSession()
+		consume(consumer, session, &sarama.ConsumerMessage{Topic: "payment-commands", Offset: 7, Value: []byte("{}")})
+
+		assert.Equal(t, 3, calls)
+		assert.Equal(t, []int64{7}, session.marked)
+		assert.Empty(t, producer.sent)
+	})
+
+	t.Run("should dead-letter messages once attempts run out", func(t *testing.T) {
+		producer := &MockSyncProducer{}
+		calls := 0
+		consumer := kafka.NewConsumerWithClients(consumerConfig(), nil, producer, kafka.HandlerFunc(func(ctx context.Context, message *kafka.Message) error {
+			calls++
+			return errors.New("temporarily unavailable")
+		}))
+
+		session := NewMockConsumerGroupSession()
+		consume(consumer, session,
+			&sarama.ConsumerMessage{Topic: "payment-commands", Partition: 2, Offset: 7, Key: []byte("cmd_1"), Value: []byte("{}")},
+			&sarama.ConsumerMessage{Topic: "payment-commands", Partition: 2, Offset: 8, Value: []byte("{}")},
+		)
+
+		assert.Equal(t, 6, calls)
+		assert.Equal(t, []int64{7, 8}, session.marked)
+		require.Len(t, producer.sent, 2)
+
+		dead := producer.sent[0]
+		assert.Equal(t, "payment-commands.dlq", dead.Topic)
+		headers := map[string]string{}
+		for _, header := range dead.Headers {
+			headers[string(header.Key)] = string(header.Value)
+		}
+		assert.Equal(t, "temporarily unavailable", headers[kafka.HeaderError])
+		assert.Equal(t, "payment-commands", headers[kafka.HeaderTopic])
+		assert.Equal(t, "2", headers[kafka.HeaderPartition])
+		assert.Equal(t, "7", headers[kafka.HeaderOffset])
+		assert.Equal(t, "3", headers[kafka.HeaderAttempts])
+	})
+
+	t.Run("should dead-letter permanent failures without retrying", func(t *testing.T) {
+		service, _, _, _ := setup()
+		producer := &MockSyncProducer{}
+		consumer := kafka.NewConsumerWithClients(consumerConfig(), nil, producer, service)
+
+		session := NewMockConsumerGroupSession()
+		consume(consumer, session, &sarama.ConsumerMessage{Topic: "payment-commands", Offset: 3, Value: []byte("not json")})
+
+		assert.Equal(t, []int64{3}, session.marked)
+		require.Len(t, producer.sent, 1)
+	})
+
+	t.Run("should leave messages unmarked when the session ends before they settle", func(t *testing.T) {
+		producer := &MockSyncProducer{err: errors.New("broker unavailable")}
+		consumer := kafka.NewConsumerWithClients(consumerConfig(), nil, producer, kafka.HandlerFunc(func(ctx context.Context, message *kafka.Message) error {
+			return kafka.ErrPermanent
+		}))
+
+		session := NewMockConsumerGroupSession()
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			session.cancel()
+		}()
+		consume(consumer, session, &sarama.ConsumerMessage{Topic: "payment-commands", Offset: 3, Value: []byte("{}")})
+
+		assert.Empty(t, session.marked)
+	})
+}
+
+// MockCommandRefunder records refunds requested by commands
+type MockCommandRefunder struct {
+	actors   []refundguard.Actor
+	requests []*stripe.RefundRequest
+	err      error
+}
+
+// NewMockCommandRefunder creates a refunder with no refunds issued
+func NewMockCommandRefunder() *MockCommandRefunder {
+	return &MockCommandRefunder{}
+}
+
+func (m *MockCommandRefunder) CreateRefund(ctx context.Context, actor refundguard.Actor, request *stripe.RefundRequest) (*stripe.Refund, *refundguard.Approval, error) {
+	if m.err != nil {
+		return nil, nil, m.err
+	}
+	m.actors = append(m.actors, actor)
+	m.requests = append(m.requests, request)
+	return &stripe.Refund{ID: "re_123", ChargeID: request.ChargeID, Amount: request.Amount, Status: "succeeded"}, nil, nil
+}
+
+// MockCommandChargeStates allows every transition unless err is set
+type MockCommandChargeStates struct {
+	err error
+}
+
+func (m *MockCommandChargeStates) Allows(ctx context.Context, chargeID string, to ...string) error {
+	return m.err
+}
+
+// MockCommandCapturer captures charges in full
+type MockCommandCapturer struct{}
+
+func (m *MockCommandCapturer) CaptureCharge(ctx context.Context, chargeID string, amount int64) (*stripe.Charge, error) {
+	return &stripe.Charge{ID: chargeID, Amount: amount, Captured: true}, nil
+}
+
+// MockCommandCanceller cancels subscriptions
+type MockCommandCanceller struct{}
+
+func (m *MockCommandCanceller) CancelSubscription(ctx context.Context, subscriptionID string, atPeriodEnd bool) (*stripe.Subscription, error) {
+	return &stripe.Subscription{ID: subscriptionID, Status: "canceled"}, nil
+}
+
+// MockSyncProducer records messages sent to Kafka
+type MockSyncProducer struct {
+	sarama.SyncProducer
+	sent []*sarama.ProducerMessage
+	err  error
+}
+
+func (m *MockSyncProducer) SendMessage(message *sarama.ProducerMessage) (int32, int64, error) {
+	if m.err != nil {
+		return 0, 0, m.err
+	}
+	m.sent = append(m.sent, message)
+	return 0, int64(len(m.sent)), nil
+}
+
+// MockConsumerGroupSession records the offsets of marked messages
+type MockConsumerGroupSession struct {
+	sarama.ConsumerGroupSession
+	ctx    context.Context
+	cancel context.CancelFunc
+	marked []int64
+}
+
+// NewMockConsumerGroupSession creates a session that runs until cancelled
+func NewMockConsumerGroupSession() *MockConsumerGroupSession {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &MockConsumerGroupSession{ctx: ctx, cancel: cancel}
+}
+
+func (m *MockConsumerGroupSession) Context() context.Context {
+	return m.ctx
+}
+
+func (m *MockConsumerGroupSession) MarkMessage(message *sarama.ConsumerMessage, metadata string) {
+	m.marked = append(m.marked, message.Offset)
+}
+
+// MockConsumerGroupClaim delivers a fixed set of messages
+type MockConsumerGroupClaim struct {
+	sarama.ConsumerGroupClaim
+	messages chan *sarama.ConsumerMessage
+}
+
+func (m *MockConsumerGroupClaim) Messages() <-chan *sarama.ConsumerMessage {
+	return m.messages
+}
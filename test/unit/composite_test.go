@@ -0,0 +1,287 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"apis/payments/services/composite"
+	"apis/payments/services/refundguard"
+	"apis/payments/services/stripe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCompositeRefunds tests splitting refunds of composite charges across their payment legs
+func TestCompositeRefunds(t *testing.T) {
+	legs := func() []*composite.Leg {
+		return []*composite.Leg{
+			{ChargeID: "ch_card", Amount: 7000, Priority: 2},
+			{ChargeID: "ch_gift", Amount: 3000, Priority: 1},
+		}
+	}
+	newService := func(charges *MockCompositeChargeLookup, refunder *MockCompositeRefunder) (*composite.Service, *MockCompositeStore) {
+		store := NewMockCompositeStore()
+		return composite.NewService(store, charges, refunder), store
+	}
+	actor := refundguard.Actor{TenantID: "tenant_1", OperatorID: "op_1"}
+
+	t.Run("should split proportionally with parts adding up to the amount", func(t *testing.T) {
+		parts, err := composite.Split(composite.StrategyProportional, legs(), []int64{7000, 3000}, 1000)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{700, 300}, parts)
+
+		threeLegs := append(legs(), &composite.Leg{ChargeID: "ch_wallet", Amount: 100})
+		parts, err = composite.Split(composite.StrategyProportional, threeLegs, []int64{100, 100, 100}, 100)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{34, 33, 33}, parts)
+	})
+
+	t.Run("should refund legs in priority order", func(t *testing.T) {
+		parts, err := composite.Split(composite.StrategyPriority, legs(), []int64{7000, 3000}, 4000)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{1000, 3000}, parts)
+	})
+
+	t.Run("should reject refunds larger than the legs can refund", func(t *testing.T) {
+		_, err := composite.Split(composite.StrategyProportional, legs(), []int64{7000, 1000}, 9000)
+		assert.ErrorIs(t, err, composite.ErrExceedsRefundable)
+
+		_, err = composite.Split("random", legs(), []int64{7000, 3000}, 100)
+		assert.ErrorIs(t, err, composite.ErrInvalidRefund)
+	})
+
+	t.Run("should combine leg statuses", func(t *testing.T) {
+		status := func(statuses ...string) string {
+			refundLegs := make([]*composite.LegRefund, len(statuses))
+			for i, s := range statuses {
+				refundLegs[i] = &composite.LegRefund{Status: s}
+			}
+			return composite.CombinedStatus(refundLegs)
+		}
+
+		assert.Equal(t, composite.StatusSucceeded, status(composite.LegStatusSucceeded, composite.LegStatusSucceeded))
+		assert.Equal(t, composite.StatusPending, status(composite.LegStatusSucceeded, composite.LegStatusPendingApproval))
+		assert.Equal(t, composite.StatusPartiallyFailed, status(composite.LegStatusSucceeded, composite.LegStatusFailed))
+		assert.Equal(t, composite.StatusFailed, status(composite.LegStatusFailed, composite.LegStatusCanceled))
+	})
+
+	t.Run("should reject legs that cannot be grouped", func(t *testing.T) {
+		charges := NewMockCompositeChargeLookup(
+			&stripe.Charge{ID: "ch_card", Amount: 7000, Currency: "usd", Status: "succeeded"},
+			&stripe.Charge{ID: "ch_eur", Amount: 3000, Currency: "eur", Status: "succeeded"},
+			&stripe.Charge{ID: "ch_failed", Amount: 3000, Currency: "usd", Status: "failed"},
+		)
+		service, _ := newService(charges, NewMockCompositeRefunder())
+
+		for _, chargeIDs := range [][]string{{"ch_card"}, {"ch_card", "ch_card"}, {"ch_card", "ch_eur"}, {"ch_card", "ch_failed"}} {
+			request := &composite.CreateChargeRequest{}
+			for _, chargeID := range chargeIDs {
+				request.Legs = append(request.Legs, composite.LegRequest{ChargeID: chargeID})
+			}
+
+			_, err := service.CreateCharge(context.Background(), "tenant_1", request)
+			assert.ErrorIs(t, err, composite.ErrInvalidCompositeCharge, chargeIDs)
+		}
+	})
+
+	t.Run("should refund each leg and report held legs", func(t *testing.T) {
+		charges := NewMockCompositeChargeLookup(
+			&stripe.Charge{ID: "ch_card", Amount: 7000, Currency: "usd", Status: "succeeded"},
+			&stripe.Charge{ID: "ch_gift", Amount: 3000, AmountRefunded: 1000, Currency: "usd", Status: "succeeded"},
+		)
+		refunder := NewMockCompositeRefunder()
+		refunder.hold["ch_gift"] = true
+		service, _ := newService(charges, refunder)
+
+		request := &composite.CreateChargeRequest{
+			Legs: []composite.LegRequest{{ChargeID: "ch_card"}, {ChargeID: "ch_gift"}},
+		}
+		charge, err := service.CreateCharge(context.Background(), "tenant_1", request)
+		require.NoError(t, err)
+		assert.Equal(t, int64(10000), charge.Amount)
+		assert.Equal(t, composite.StrategyProportional, charge.RefundStrategy)
+
+		refund, err := service.Refund(context.Background(), actor, charge.ID, &composite.RefundRequest{Amount: 900})
+		require.NoError(t, err)
+		assert.Equal(t, composite.StatusPending, refund.Status)
+		require.Len(t, refund.Legs, 2)
+		assert.Equal(t, int64(700), refund.Legs[0].Amount)
+		assert.Equal(t, composite.LegStatusSucceeded, refund.Legs[0].Status)
+		assert.Equal(t, "re_ch_card", refund.Legs[0].RefundID)
+		assert.Equal(t, int64(200), refund.Legs[1].Amount)
+		assert.Equal(t, composite.LegStatusPendingApproval, refund.Legs[1].Status)
+		assert.Equal(t, "apr_ch_gift", refund.Legs[1].ApprovalID)
+		assert.Equal(t, refund.ID, refunder.requests[0].Metadata[composite.RefundMetadataKey])
+
+		// The held leg is set aside until it is approved or rejected
+		charges.charges["ch_card"].AmountRefunded = 700
+		_, err = service.Refund(context.Background(), actor, charge.ID, &composite.RefundRequest{Amount: 8200})
+		assert.ErrorIs(t, err, composite.ErrExceedsRefundable)
+
+		// Approving the held leg issues a refund carrying the composite refund ID
+		err = service.HandleRefundUpdate(context.Background(), refund.ID, &stripe.Refund{ID: "re_gift", ChargeID: "ch_gift", Status: "succeeded"}, "")
+		require.NoError(t, err)
+
+		updated, err := service.GetRefund(context.Background(), refund.ID)
+		require.NoError(t, err)
+		assert.Equal(t, composite.StatusSucceeded, updated.Status)
+		assert.Equal(t, "re_gift", updated.Legs[1].RefundID)
+		assert.Empty(t, updated.Legs[1].ApprovalID)
+	})
+
+	t.Run("should cancel legs whose approval is rejected", func(t *testing.T) {
+		charges := NewMockCompositeChargeLookup(
+			&stripe.Charge{ID: "ch_card", Amount: 7000, Currency: "usd", Status: "succeeded"},
+			&stripe.Charge{ID: "ch_gift", Amount: 3000, Currency: "usd", Status: "succeeded"},
+		)
+		refunder := NewMockCompositeRefunder()
+		refunder.hold["ch_gift"] = true
+		refunder.fail["ch_card"] = true
+		service, _ := newService(charges, refunder)
+
+		request := &composite.CreateChargeRequest{
+			Legs:           []composite.LegRequest{{ChargeID: "ch_card", Priority: 1}, {ChargeID: "ch_gift", Priority: 2}},
+			RefundStrategy: composite.StrategyPriority,
+		}
+		charge, err := service.CreateCharge(context.Background(), "tenant_1", request)
+		require.NoError(t, err)
+
+		refund, err := service.Refund(context.Background(), actor, charge.ID, &composite.RefundRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(10000), refund.Amount)
+		assert.Equal(t, composite.LegStatusFailed, refund.Legs[0].Status)
+		assert.Equal(t, "card declined", refund.Legs[0].FailureReason)
+
+		approval := &refundguard.Approval{ID: "apr_ch_gift", Request: *refunder.requests[1]}
+		require.NoError(t, service.HandleApprovalRejected(context.Background(), approval))
+
+		updated, err := service.GetRefund(context.Background(), refund.ID)
+		require.NoError(t, err)
+		assert.Equal(t, composite.LegStatusCanceled, updated.Legs[1].Status)
+		assert.Equal(t, composite.StatusFailed, updated.Status)
+	})
+}
+
+// MockCompositeStore keeps composite charges and refunds in memory
+type MockCompositeStore struct {
+	charges map[string]*composite.Charge
+	refunds map[string]*composite.Refund
+	order   []string
+}
+
+// NewMockCompositeStore creates an empty composite store
+func NewMockCompositeStore() *MockCompositeStore {
+	return &MockCompositeStore{
+		charges: make(map[string]*composite.Charge),
+		refunds: make(map[string]*composite.Refund),
+	}
+}
+
+func (m *MockCompositeStore) CreateCompositeCharge(ctx context.Context, charge *composite.Charge) (*composite.Charge, error) {
+	m.charges[charge.ID] = charge
+	return charge, nil
+}
+
+func (m *MockCompositeStore) GetCompositeCharge(ctx context.Context, id string) (*composite.Charge, error) {
+	charge, ok := m.charges[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return charge, nil
+}
+
+func (m *MockCompositeStore) CreateCompositeRefund(ctx context.Context, refund *composite.Refund) (*composite.Refund, error) {
+	m.refunds[refund.ID] = refund
+	m.order = append(m.order, refund.ID)
+	return refund, nil
+}
+
+func (m *MockCompositeStore) GetCompositeRefund(ctx context.Context, id string) (*composite.Refund, error) {
+	refund, ok := m.refunds[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return refund, nil
+}
+
+func (m *MockCompositeStore) ListCompositeRefunds(ctx context.Context, compositeChargeID string) ([]*composite.Refund, error) {
+	var refunds []*composite.Refund
+	for _, id := range m.order {
+		if m.refunds[id].CompositeChargeID == compositeChargeID {
+			refunds = append(refunds, m.refunds[id])
+		}
+	}
+	return refunds, nil
+}
+
+func (m *MockCompositeStore) UpdateCompositeRefundLeg(ctx context.Context, refundID string, leg *composite.LegRefund) (*composite.LegRefund, error) {
+	refund, ok := m.refunds[refundID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	for i, stored := range refund.Legs {
+		if stored.ChargeID == leg.ChargeID {
+			updated := *leg
+			refund.Legs[i] = &updated
+			return &updated, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *MockCompositeStore) UpdateCompositeRefundStatus(ctx context.Context, id, status string) error {
+	refund, ok := m.refunds[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	refund.Status = status
+	return nil
+}
+
+// MockCompositeChargeLookup returns leg charges by ID
+type MockCompositeChargeLookup struct {
+	charges map[string]*stripe.Charge
+}
+
+// NewMockCompositeChargeLookup creates a charge lookup holding the given charges
+func NewMockCompositeChargeLookup(charges ...*stripe.Charge) *MockCompositeChargeLookup {
+	lookup := &MockCompositeChargeLookup{charges: make(map[string]*stripe.Charge)}
+	for _, charge := range charges {
+		lookup.charges[charge.ID] = charge
+	}
+	return lookup
+}
+
+func (m *MockCompositeChargeLookup) GetCharge(ctx context.Context, chargeID string) (*stripe.Charge, error) {
+	charge, ok := m.charges[chargeID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return charge, nil
+}
+
+// MockCompositeRefunder refunds legs immediately unless told to hold or fail them
+type MockCompositeRefunder struct {
+	hold     map[string]bool
+	fail     map[string]bool
+	requests []*stripe.RefundRequest
+}
+
+// NewMockCompositeRefunder creates a refunder that refunds every leg
+func NewMockCompositeRefunder() *MockCompositeRefunder {
+	return &MockCompositeRefunder{hold: make(map[string]bool), fail: make(map[string]bool)}
+}
+
+func (m *MockCompositeRefunder) CreateRefund(ctx context.Context, actor refundguard.Actor, request *stripe.RefundRequest) (*stripe.Refund, *refundguard.Approval, error) {
+	m.requests = append(m.requests, request)
+	if m.fail[request.ChargeID] {
+		return nil, nil, errors.New("card declined")
+	}
+	if m.hold[request.ChargeID] {
+		return nil, &refundguard.Approval{ID: "apr_" + request.ChargeID, Request: *request}, nil
+	}
+	return &stripe.Refund{ID: "re_" + request.ChargeID, ChargeID: request.ChargeID, Amount: request.Amount, Status: "succeeded"}, nil, nil
+}
@@ -0,0 +1,181 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"apis/payments/services/config"
+	"apis/payments/services/ratelimit"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestConfig tests layering settings from the config file under the
+// environment, reading secrets from files and Vault, validating settings and
+// reloading them
+func TestConfig(t *testing.T) {
+	ctx := context.Background()
+
+	// unset clears variables the loader sets once the test ends
+	unset := func(names ...string) {
+		t.Cleanup(func() {
+			for _, name := range names {
+				os.Unsetenv(name)
+			}
+		})
+	}
+	writeFile := func(dir, name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	t.Run("should validate settings by kind", func(t *testing.T) {
+		settings := []config.Setting{
+			{Name: "CFGTEST_PORT", Kind: config.KindInt},
+			{Name: "CFGTEST_ENABLED", Kind: config.KindBool},
+			{Name: "CFGTEST_URL", Kind: config.KindURL, Required: true},
+			{Name: "CFGTEST_RULES", Kind: config.KindString, Check: func(value string) error {
+				_, err := ratelimit.ParseRules(value)
+				return err
+			}},
+		}
+		t.Setenv("CFGTEST_PORT", "80a")
+		t.Setenv("CFGTEST_ENABLED", "true")
+		t.Setenv("CFGTEST_RULES", "POST /charges=fast")
+
+		err := config.Validate(settings)
+		assert.ErrorIs(t, err, config.ErrInvalid)
+		assert.ErrorContains(t, err, "CFGTEST_PORT must be a int")
+		assert.ErrorContains(t, err, "CFGTEST_URL is required")
+		assert.ErrorContains(t, err, "CFGTEST_RULES:")
+		assert.NotContains(t, err.Error(), "CFGTEST_ENABLED")
+	})
+
+	t.Run("should layer the environment over the file and read secrets", func(t *testing.T) {
+		dir := t.TempDir()
+		vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Vault-Token") != "s.token" || r.URL.Path != "/v1/secret/data/payments" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.Write([]byte(`{"data":{"data":{"stripe_key":"sk_from_vault"},"metadata":{"version":3}}}`))
+		}))
+		defer vault.Close()
+
+		path := writeFile(dir, "config.yaml", `
+cfgtest:
+  rate_limit:
+    rate: 5
+    endpoints:
+      - POST /charges=1:2
+      - POST /refunds=1:5
+  port: 8080
+  stripe_key: vault:secret/data/payments#stripe_key
+`)
+		t.Setenv("CFGTEST_PORT", "9000")
+		t.Setenv("CFGTEST_DB_PASSWORD_FILE", writeFile(dir, "db_password", "hunter2\n"))
+		t.Setenv("VAULT_ADDR", vault.URL)
+		t.Setenv("VAULT_TOKEN", "s.token")
+		unset("CFGTEST_RATE_LIMIT_RATE", "CFGTEST_RATE_LIMIT_ENDPOINTS", "CFGTEST_STRIPE_KEY", "CFGTEST_DB_PASSWORD")
+
+		loader := config.NewLoader(path, []config.Setting{
+			{Name: "CFGTEST_PORT", Kind: config.KindInt},
+			{Name: "CFGTEST_RATE_LIMIT_RATE", Kind: config.KindFloat, Reloadable: true},
+			{Name: "CFGTEST_STRIPE_KEY", Kind: config.KindString, Secret: true},
+			{Name: "CFGTEST_DB_PASSWORD", Kind: config.KindString, Secret: true},
+		})
+		require.NoError(t, loader.Load(ctx))
+
+		assert.Equal(t, "9000", os.Getenv("CFGTEST_PORT"), "the environment wins")
+		assert.Equal(t, "5", os.Getenv("CFGTEST_RATE_LIMIT_RATE"))
+		assert.Equal(t, "POST /charges=1:2,POST /refunds=1:5", os.Getenv("CFGTEST_RATE_LIMIT_ENDPOINTS"))
+		assert.Equal(t, "sk_from_vault", os.Getenv("CFGTEST_STRIPE_KEY"))
+		assert.Equal(t, "hunter2", os.Getenv("CFGTEST_DB_PASSWORD"))
+
+		statuses := map[string]config.Status{}
+		for _, status := range loader.Statuses() {
+			statuses[status.Name] = status
+		}
+		assert.Equal(t, config.SourceEnv, statuses["CFGTEST_PORT"].Source)
+		assert.Equal(t, config.SourceFile, statuses["CFGTEST_RATE_LIMIT_RATE"].Source)
+		assert.Equal(t, config.SourceVault, statuses["CFGTEST_STRIPE_KEY"].Source)
+		assert.Equal(t, config.SourceSecretFile, statuses["CFGTEST_DB_PASSWORD"].Source)
+		assert.Empty(t, statuses["CFGTEST_STRIPE_KEY"].Value, "secrets are not reported")
+	})
+
+	t.Run("should read numeric settings loaded from the file", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeFile(dir, "config.yaml", `
+cfgtest:
+  batch_size: 250
+  multiplier: 1.5
+`)
+		unset("CFGTEST_BATCH_SIZE", "CFGTEST_MULTIPLIER")
+
+		loader := config.NewLoader(path, []config.Setting{
+			{Name: "CFGTEST_BATCH_SIZE", Kind: config.KindInt},
+			{Name: "CFGTEST_MULTIPLIER", Kind: config.KindFloat},
+		})
+		require.NoError(t, loader.Load(ctx))
+
+		assert.Equal(t, 250, config.Int("CFGTEST_BATCH_SIZE", 100))
+		assert.Equal(t, 1.5, config.Float("CFGTEST_MULTIPLIER", 5))
+		assert.Equal(t, 100, config.Int("CFGTEST_UNSET", 100), "unset settings take the default")
+
+		t.Setenv("CFGTEST_INVALID", "ten")
+		assert.Equal(t, 10, config.Int("CFGTEST_INVALID", 10), "invalid values take the default")
+	})
+
+	t.Run("should reload only valid reloadable settings from the file", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeFile(dir, "config.yaml", "cfgtest_reload:\n  rate: 5\n  port: 8080\n")
+		unset("CFGTEST_RELOAD_RATE", "CFGTEST_RELOAD_PORT", "CFGTEST_RELOAD_BURST")
+
+		loader := config.NewLoader(path, []config.Setting{
+			{Name: "CFGTEST_RELOAD_RATE", Kind: config.KindFloat, Reloadable: true},
+			{Name: "CFGTEST_RELOAD_BURST", Kind: config.KindInt, Reloadable: true},
+			{Name: "CFGTEST_RELOAD_PORT", Kind: config.KindInt},
+		})
+		require.NoError(t, loader.Load(ctx))
+		var reloaded []string
+		loader.OnReload(func(changed []string) { reloaded = changed })
+
+		writeFile(dir, "config.yaml", "cfgtest_reload:\n  rate: 10\n  burst: 20\n  port: 9090\n")
+		changed, err := loader.Reload()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"CFGTEST_RELOAD_BURST", "CFGTEST_RELOAD_RATE"}, changed)
+		assert.Equal(t, changed, reloaded)
+		assert.Equal(t, "10", os.Getenv("CFGTEST_RELOAD_RATE"))
+		assert.Equal(t, "8080", os.Getenv("CFGTEST_RELOAD_PORT"), "other settings need a restart")
+
+		writeFile(dir, "config.yaml", "cfgtest_reload:\n  rate: fast\n")
+		_, err = loader.Reload()
+		assert.ErrorIs(t, err, config.ErrInvalid)
+		assert.Equal(t, "20", os.Getenv("CFGTEST_RELOAD_BURST"), "nothing is applied from an invalid file")
+
+		writeFile(dir, "config.yaml", "cfgtest_reload:\n  rate: 10\n")
+		changed, err = loader.Reload()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"CFGTEST_RELOAD_BURST"}, changed)
+		assert.Empty(t, os.Getenv("CFGTEST_RELOAD_BURST"), "settings removed from the file are unset")
+	})
+
+	t.Run("should apply reloaded rate limits", func(t *testing.T) {
+		service := ratelimit.NewService(ratelimit.NewMemoryStore(), nil)
+		rules, _ := service.Rules()
+		assert.Empty(t, rules)
+
+		parsed, err := ratelimit.ParseRules("POST /charges=1:2")
+		require.NoError(t, err)
+		service.Reconfigure(&ratelimit.Config{Enabled: false, Rules: parsed})
+		rules, _ = service.Rules()
+		assert.Len(t, rules, 1)
+		assert.False(t, service.Enabled())
+	})
+}
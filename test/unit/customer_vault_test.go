@@ -8,6 +8,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	stripego "github.com/stripe/stripe-go/v76"
 )
 
 // TestCustomerVault tests the customer vault and payment methods functionality
@@ -111,6 +112,41 @@ func TestCustomerVault(t *testing.T) {
 		assert.Equal(t, "pm_test123", paymentMethods[0].ID)
 		assert.Equal(t, "pm_test456", paymentMethods[1].ID)
 	})
+
+	t.Run("should validate setup intent requests", func(t *testing.T) {
+		customerService := stripe.NewCustomerService()
+
+		_, err := customerService.CreateSetupIntent(context.Background(), &stripe.SetupIntentRequest{})
+		assert.ErrorContains(t, err, "validation failed")
+
+		_, err = customerService.CreateSetupIntent(context.Background(), &stripe.SetupIntentRequest{Customer: "cus_test123", Usage: "sometimes"})
+		assert.ErrorContains(t, err, "validation failed")
+
+		_, err = customerService.ConfirmSetupIntent(context.Background(), "seti_test123", &stripe.ConfirmSetupIntentRequest{})
+		assert.ErrorContains(t, err, "validation failed")
+	})
+
+	t.Run("should convert setup intents awaiting customer action", func(t *testing.T) {
+		intent := stripe.ConvertSetupIntent(&stripego.SetupIntent{
+			ID:            "seti_test123",
+			ClientSecret:  "seti_test123_secret_abc",
+			Customer:      &stripego.Customer{ID: "cus_test123"},
+			PaymentMethod: &stripego.PaymentMethod{ID: "pm_test123"},
+			Status:        stripego.SetupIntentStatusRequiresAction,
+			Usage:         stripego.SetupIntentUsageOffSession,
+			NextAction:    &stripego.SetupIntentNextAction{Type: stripego.SetupIntentNextActionTypeUseStripeSDK},
+		})
+
+		assert.Equal(t, "cus_test123", intent.Customer)
+		assert.Equal(t, "pm_test123", intent.PaymentMethod)
+		assert.Equal(t, stripe.SetupIntentRequiresAction, intent.Status)
+		assert.Equal(t, "use_stripe_sdk", intent.NextActionType)
+		assert.Equal(t, "seti_test123_secret_abc", intent.ClientSecret)
+
+		// The card is only saved once the customer completes the action
+		_, err := stripe.NewCustomerService().SetupIntentPaymentMethod(context.Background(), intent)
+		assert.ErrorIs(t, err, stripe.ErrSetupIntentNotSucceeded)
+	})
 }
 
 // MockCustomerService is a mock implementation for testing
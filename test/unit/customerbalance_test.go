@@ -0,0 +1,356 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+
+	"apis/payments/services/customerbalance"
+	"apis/payments/services/stripe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCustomerBalance tests issuing credits to customers, spending them on
+// charges and invoices, and reconciling them with the Stripe balance
+func TestCustomerBalance(t *testing.T) {
+	ctx := context.Background()
+	newService := func(provider *MockCustomerBalanceProvider) (*customerbalance.Service, *MockCustomerBalanceStore, *MockCreditCharges) {
+		store := NewMockCustomerBalanceStore()
+		charges := &MockCreditCharges{charges: make(map[string]*stripe.Charge)}
+		return customerbalance.NewService(store, provider, charges), store, charges
+	}
+	goodwill := func(amount int64, currency string) *customerbalance.CreditRequest {
+		return &customerbalance.CreditRequest{Amount: amount, Currency: currency, Reason: customerbalance.ReasonGoodwill}
+	}
+
+	t.Run("should issue credits and sync those in the provider currency", func(t *testing.T) {
+		provider := &MockCustomerBalanceProvider{currency: "usd"}
+		service, _, _ := newService(provider)
+
+		credit, err := service.Credit(ctx, "tenant_1", "cus_1", goodwill(1500, "USD"))
+		require.NoError(t, err)
+		assert.Equal(t, customerbalance.TypeCredit, credit.Type)
+		assert.Equal(t, "usd", credit.Currency)
+		assert.Equal(t, int64(1500), credit.BalanceAfter)
+		assert.NotEmpty(t, credit.ProviderTransactionID)
+		assert.Equal(t, int64(-1500), provider.balance)
+
+		euro, err := service.Credit(ctx, "tenant_1", "cus_1", goodwill(800, "eur"))
+		require.NoError(t, err)
+		assert.Empty(t, euro.ProviderTransactionID, "the provider holds the balance in one currency")
+		assert.Equal(t, int64(-1500), provider.balance)
+
+		summary, err := service.Summary(ctx, "tenant_1", "cus_1", 0)
+		require.NoError(t, err)
+		require.Len(t, summary.Balances, 2)
+		assert.Equal(t, "eur", summary.Balances[0].Currency)
+		assert.Equal(t, int64(800), summary.Balances[0].Amount)
+		assert.Equal(t, int64(1500), summary.Balances[1].Amount)
+		require.Len(t, summary.Transactions, 2)
+		assert.Equal(t, euro.ID, summary.Transactions[0].ID)
+	})
+
+	t.Run("should reject invalid credits", func(t *testing.T) {
+		service, _, _ := newService(&MockCustomerBalanceProvider{})
+
+		for name, request := range map[string]*customerbalance.CreditRequest{
+			"zero amount":         goodwill(0, "usd"),
+			"invalid currency":    goodwill(100, "dollars"),
+			"unknown reason":      {Amount: 100, Currency: "usd", Reason: "bonus"},
+			"refund of no charge": {Amount: 100, Currency: "usd", Reason: customerbalance.ReasonRefund},
+		} {
+			_, err := service.Credit(ctx, "tenant_1", "cus_1", request)
+			assert.ErrorIs(t, err, customerbalance.ErrInvalidCredit, name)
+		}
+	})
+
+	t.Run("should refund no more of a charge to the balance than is left to refund", func(t *testing.T) {
+		service, _, charges := newService(&MockCustomerBalanceProvider{currency: "usd"})
+		charges.charges["ch_1"] = &stripe.Charge{ID: "ch_1", CustomerID: "cus_1", Amount: 5000, AmountRefunded: 1000, Currency: "usd", Status: "succeeded"}
+		refund := func(amount int64) *customerbalance.CreditRequest {
+			return &customerbalance.CreditRequest{Amount: amount, Currency: "usd", Reason: customerbalance.ReasonRefund, ChargeID: "ch_1"}
+		}
+
+		credit, err := service.Credit(ctx, "tenant_1", "cus_1", refund(3000))
+		require.NoError(t, err)
+		assert.Equal(t, "ch_1", credit.SourceID)
+
+		_, err = service.Credit(ctx, "tenant_1", "cus_1", refund(1001))
+		assert.ErrorIs(t, err, customerbalance.ErrRefundExceedsCharge)
+
+		_, err = service.Credit(ctx, "tenant_1", "cus_2", refund(100))
+		assert.ErrorIs(t, err, customerbalance.ErrInvalidCredit, "charges of other customers")
+	})
+
+	t.Run("should apply the balance to charges before the payment method", func(t *testing.T) {
+		provider := &MockCustomerBalanceProvider{currency: "usd"}
+		service, store, _ := newService(provider)
+		_, err := service.Credit(ctx, "tenant_1", "cus_1", goodwill(1500, "usd"))
+		require.NoError(t, err)
+
+		request := &stripe.ChargeRequest{CustomerID: "cus_1", AmountDecimal: "40.00", Currency: "usd", PaymentMethod: "pm_card"}
+		application, err := service.Apply(ctx, "tenant_1", request)
+		require.NoError(t, err)
+		require.NotNil(t, application)
+
+		assert.Equal(t, int64(1500), application.Applied)
+		assert.False(t, application.Covered())
+		assert.Equal(t, int64(2500), request.Amount)
+		assert.Empty(t, request.AmountDecimal)
+		assert.Equal(t, "1500", request.Metadata["balance_applied"])
+		assert.Equal(t, application.Transaction.ID, request.Metadata["balance_transaction"])
+		assert.Equal(t, int64(0), provider.balance, "spent credit leaves the provider balance")
+
+		require.NoError(t, service.Settle(ctx, application, "ch_1"))
+		assert.Equal(t, "ch_1", store.transactions[1].SourceID)
+	})
+
+	t.Run("should charge nothing when the balance covers the charge", func(t *testing.T) {
+		service, store, _ := newService(&MockCustomerBalanceProvider{})
+		_, err := service.Credit(ctx, "tenant_1", "cus_1", goodwill(5000, "usd"))
+		require.NoError(t, err)
+
+		application, err := service.Apply(ctx, "tenant_1", &stripe.ChargeRequest{CustomerID: "cus_1", Amount: 2000, Currency: "usd"})
+		require.NoError(t, err)
+
+		assert.True(t, application.Covered())
+		assert.Equal(t, int64(3000), store.balances["tenant_1/cus_1/usd"])
+	})
+
+	t.Run("should leave the currency minimum to charge", func(t *testing.T) {
+		service, _, _ := newService(&MockCustomerBalanceProvider{})
+		_, err := service.Credit(ctx, "tenant_1", "cus_1", goodwill(1990, "usd"))
+		require.NoError(t, err)
+
+		request := &stripe.ChargeRequest{CustomerID: "cus_1", Amount: 2000, Currency: "usd"}
+		application, err := service.Apply(ctx, "tenant_1", request)
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(1950), application.Applied)
+		assert.Equal(t, int64(50), request.Amount)
+	})
+
+	t.Run("should skip charges without a customer or balance", func(t *testing.T) {
+		service, _, _ := newService(&MockCustomerBalanceProvider{})
+
+		for _, request := range []*stripe.ChargeRequest{
+			{Amount: 2000, Currency: "usd"},
+			{CustomerID: "cus_1", Amount: 2000, Currency: "usd"},
+		} {
+			application, err := service.Apply(ctx, "tenant_1", request)
+			require.NoError(t, err)
+			assert.Nil(t, application)
+			assert.Equal(t, int64(2000), request.Amount)
+		}
+	})
+
+	t.Run("should restore the balance of charges that were not created", func(t *testing.T) {
+		provider := &MockCustomerBalanceProvider{currency: "usd"}
+		service, store, _ := newService(provider)
+		_, err := service.Credit(ctx, "tenant_1", "cus_1", goodwill(1000, "usd"))
+		require.NoError(t, err)
+		application, err := service.Apply(ctx, "tenant_1", &stripe.ChargeRequest{CustomerID: "cus_1", Amount: 3000, Currency: "usd"})
+		require.NoError(t, err)
+
+		reversal, err := service.Reverse(ctx, application)
+		require.NoError(t, err)
+
+		assert.Equal(t, customerbalance.TypeReversal, reversal.Type)
+		assert.Equal(t, application.Transaction.ID, reversal.SourceID)
+		assert.Equal(t, int64(1000), store.balances["tenant_1/cus_1/usd"])
+		assert.Equal(t, int64(-1000), provider.balance)
+	})
+
+	t.Run("should restore the balance when the provider cannot be updated", func(t *testing.T) {
+		provider := &MockCustomerBalanceProvider{currency: "usd"}
+		service, store, _ := newService(provider)
+		_, err := service.Credit(ctx, "tenant_1", "cus_1", goodwill(1000, "usd"))
+		require.NoError(t, err)
+
+		provider.err = errors.New("stripe unavailable")
+		_, err = service.Apply(ctx, "tenant_1", &stripe.ChargeRequest{CustomerID: "cus_1", Amount: 3000, Currency: "usd"})
+		assert.Error(t, err)
+		assert.Equal(t, int64(1000), store.balances["tenant_1/cus_1/usd"])
+	})
+
+	t.Run("should record the balance Stripe applied to invoices once", func(t *testing.T) {
+		service, store, _ := newService(&MockCustomerBalanceProvider{currency: "usd"})
+		_, err := service.Credit(ctx, "tenant_1", "cus_1", goodwill(1000, "usd"))
+		require.NoError(t, err)
+
+		for i := 0; i < 2; i++ {
+			require.NoError(t, service.RecordInvoice(ctx, "tenant_1", "cus_1", "in_1", "usd", -1000, -400))
+		}
+
+		assert.Equal(t, int64(400), store.balances["tenant_1/cus_1/usd"])
+		require.Len(t, store.transactions, 2)
+		assert.Equal(t, customerbalance.TypeInvoice, store.transactions[1].Type)
+		assert.Equal(t, int64(-600), store.transactions[1].Amount)
+	})
+
+	t.Run("should reconcile the balance with the provider's", func(t *testing.T) {
+		provider := &MockCustomerBalanceProvider{currency: "usd"}
+		service, store, _ := newService(provider)
+		_, err := service.Credit(ctx, "tenant_1", "cus_1", goodwill(1000, "usd"))
+		require.NoError(t, err)
+
+		// Credit added in the Stripe dashboard
+		provider.balance -= 250
+		reconciliation, err := service.Reconcile(ctx, "tenant_1", "cus_1")
+		require.NoError(t, err)
+
+		require.NotNil(t, reconciliation.Adjustment)
+		assert.Equal(t, int64(250), reconciliation.Adjustment.Amount)
+		assert.Equal(t, int64(1250), reconciliation.Balance)
+		assert.Equal(t, int64(1250), store.balances["tenant_1/cus_1/usd"])
+
+		reconciliation, err = service.Reconcile(ctx, "tenant_1", "cus_1")
+		require.NoError(t, err)
+		assert.Nil(t, reconciliation.Adjustment)
+	})
+
+}
+
+// MockCustomerBalanceStore keeps customer balances and transactions in memory
+type MockCustomerBalanceStore struct {
+	mu           sync.Mutex
+	balances     map[string]int64
+	transactions []*customerbalance.Transaction
+}
+
+func NewMockCustomerBalanceStore() *MockCustomerBalanceStore {
+	return &MockCustomerBalanceStore{balances: make(map[string]int64)}
+}
+
+func (m *MockCustomerBalanceStore) GetCustomerBalance(ctx context.Context, tenantID, customerID, currency string) (*customerbalance.Balance, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	amount, ok := m.balances[tenantID+"/"+customerID+"/"+currency]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &customerbalance.Balance{Currency: currency, Amount: amount}, nil
+}
+
+func (m *MockCustomerBalanceStore) ListCustomerBalances(ctx context.Context, tenantID, customerID string) ([]*customerbalance.Balance, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var balances []*customerbalance.Balance
+	for key, amount := range m.balances {
+		prefix := tenantID + "/" + customerID + "/"
+		if len(key) > len(prefix) && key[:len(prefix)] == prefix {
+			balances = append(balances, &customerbalance.Balance{Currency: key[len(prefix):], Amount: amount})
+		}
+	}
+	sort.Slice(balances, func(i, j int) bool { return balances[i].Currency < balances[j].Currency })
+	return balances, nil
+}
+
+func (m *MockCustomerBalanceStore) AdjustCustomerBalance(ctx context.Context, tenantID, customerID, currency string, amount int64) (*customerbalance.Balance, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := tenantID + "/" + customerID + "/" + currency
+	if m.balances[key]+amount < 0 {
+		return nil, sql.ErrNoRows
+	}
+	m.balances[key] += amount
+	return &customerbalance.Balance{Currency: currency, Amount: m.balances[key]}, nil
+}
+
+func (m *MockCustomerBalanceStore) CreateCustomerBalanceTransaction(ctx context.Context, transaction *customerbalance.Transaction) (*customerbalance.Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.transactions = append(m.transactions, transaction)
+	return transaction, nil
+}
+
+func (m *MockCustomerBalanceStore) ListCustomerBalanceTransactions(ctx context.Context, tenantID, customerID string, limit int) ([]*customerbalance.Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var transactions []*customerbalance.Transaction
+	for i := len(m.transactions) - 1; i >= 0 && len(transactions) < limit; i-- {
+		if m.transactions[i].TenantID == tenantID && m.transactions[i].CustomerID == customerID {
+			transactions = append(transactions, m.transactions[i])
+		}
+	}
+	return transactions, nil
+}
+
+func (m *MockCustomerBalanceStore) GetCustomerBalanceTransactionBySource(ctx context.Context, tenantID, transactionType, sourceID string) (*customerbalance.Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, transaction := range m.transactions {
+		if transaction.TenantID == tenantID && transaction.Type == transactionType && transaction.SourceID == sourceID {
+			return transaction, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *MockCustomerBalanceStore) SumCustomerBalanceTransactionsBySource(ctx context.Context, tenantID, transactionType, sourceID string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var total int64
+	for _, transaction := range m.transactions {
+		if transaction.TenantID == tenantID && transaction.Type == transactionType && transaction.SourceID == sourceID {
+			total += transaction.Amount
+		}
+	}
+	return total, nil
+}
+
+func (m *MockCustomerBalanceStore) SetCustomerBalanceTransactionSource(ctx context.Context, id, sourceID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, transaction := range m.transactions {
+		if transaction.ID == id {
+			transaction.SourceID = sourceID
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+// MockCustomerBalanceProvider holds one customer's Stripe balance, with
+// credit negative
+type MockCustomerBalanceProvider struct {
+	currency     string
+	balance      int64
+	transactions int
+	err          error
+}
+
+func (m *MockCustomerBalanceProvider) GetCustomerBalance(ctx context.Context, customerID string) (*stripe.CustomerBalance, error) {
+	return &stripe.CustomerBalance{Balance: m.balance, Currency: m.currency}, nil
+}
+
+func (m *MockCustomerBalanceProvider) AdjustCustomerBalance(ctx context.Context, customerID string, amount int64, currency, description string) (string, error) {
+	if m.err != nil {
+		return "", m.err
+	}
+	if m.currency == "" {
+		m.currency = currency
+	}
+	m.balance += amount
+	m.transactions++
+	return fmt.Sprintf("cbtxn_stripe_%d", m.transactions), nil
+}
+
+// MockCreditCharges returns the charges refunded to balances
+type MockCreditCharges struct {
+	charges map[string]*stripe.Charge
+}
+
+func (m *MockCreditCharges) GetCharge(ctx context.Context, chargeID string) (*stripe.Charge, error) {
+	charge, ok := m.charges[chargeID]
+	if !ok {
+		return nil, fmt.Errorf("no such charge: %s", chargeID)
+	}
+	return charge, nil
+}
@@ -0,0 +1,258 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"apis/payments/services/customers"
+	"apis/payments/services/events"
+	"apis/payments/services/stripe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCustomerLifecycle tests soft deletion with a retention period, purging
+// and erasing customers' personal data
+func TestCustomerLifecycle(t *testing.T) {
+	ctx := context.Background()
+	setup := func(retention time.Duration) (*customers.Lifecycle, *MockCustomerLifecycleStore, *MockCustomerLifecycleProvider, *MockEventPublisher) {
+		store := NewMockCustomerLifecycleStore()
+		provider := &MockCustomerLifecycleProvider{
+			customers:      map[string]bool{"cus_1": true, "cus_2": true},
+			paymentMethods: map[string][]string{"cus_1": {"pm_1", "pm_2"}},
+		}
+		publisher := &MockEventPublisher{}
+		source, err := events.NewSource("/payments")
+		require.NoError(t, err)
+		config := &customers.Config{RetentionPeriod: retention, PurgeInterval: time.Hour}
+		return customers.NewLifecycle(store, provider, events.NewEmitter(source, publisher), config), store, provider, publisher
+	}
+
+	t.Run("should keep a deleted customer until its retention period ends", func(t *testing.T) {
+		lifecycle, _, provider, _ := setup(30 * 24 * time.Hour)
+
+		deletion, err := lifecycle.Delete(ctx, "acme", "cus_1")
+		require.NoError(t, err)
+		assert.Equal(t, 30*24*time.Hour, deletion.PurgeAfter.Sub(deletion.DeletedAt))
+		assert.Nil(t, deletion.PurgedAt)
+		assert.Empty(t, provider.deleted)
+		assert.ErrorIs(t, lifecycle.Check(ctx, "cus_1"), customers.ErrCustomerDeleted)
+
+		again, err := lifecycle.Delete(ctx, "acme", "cus_1")
+		require.NoError(t, err)
+		assert.Equal(t, deletion.DeletedAt, again.DeletedAt, "deleting again keeps the first deletion")
+
+		_, err = lifecycle.Delete(ctx, "acme", "cus_missing")
+		assert.Error(t, err)
+	})
+
+	t.Run("should restore a customer within its retention period", func(t *testing.T) {
+		lifecycle, _, _, _ := setup(time.Hour)
+
+		_, err := lifecycle.Delete(ctx, "acme", "cus_1")
+		require.NoError(t, err)
+		require.NoError(t, lifecycle.Restore(ctx, "cus_1"))
+		assert.NoError(t, lifecycle.Check(ctx, "cus_1"))
+		assert.ErrorIs(t, lifecycle.Restore(ctx, "cus_1"), customers.ErrNotRestorable)
+	})
+
+	t.Run("should purge due customers and retry failures on the next run", func(t *testing.T) {
+		lifecycle, store, provider, _ := setup(time.Hour)
+		_, err := lifecycle.Delete(ctx, "acme", "cus_1")
+		require.NoError(t, err)
+		_, err = lifecycle.Delete(ctx, "acme", "cus_2")
+		require.NoError(t, err)
+
+		purged, err := lifecycle.PurgeDue(ctx, time.Now())
+		require.NoError(t, err)
+		assert.Zero(t, purged, "nothing is due within the retention period")
+
+		provider.deleteErr = map[string]error{"cus_2": errors.New("rate limited")}
+		purged, err = lifecycle.PurgeDue(ctx, time.Now().Add(2*time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, 1, purged)
+		assert.Equal(t, []string{"cus_1"}, provider.deleted)
+		assert.Equal(t, []string{"cus_1"}, store.forgotten)
+		assert.Nil(t, store.deletions["cus_2"].PurgedAt, "failed purges are released")
+		assert.ErrorIs(t, lifecycle.Restore(ctx, "cus_1"), customers.ErrNotRestorable, "purged customers can't be restored")
+
+		provider.deleteErr = nil
+		purged, err = lifecycle.PurgeDue(ctx, time.Now().Add(2*time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, 1, purged)
+		assert.Equal(t, []string{"cus_1", "cus_2"}, provider.deleted)
+	})
+
+	t.Run("should delete immediately without a retention period", func(t *testing.T) {
+		lifecycle, store, provider, _ := setup(0)
+
+		deletion, err := lifecycle.Delete(ctx, "acme", "cus_1")
+		require.NoError(t, err)
+		assert.NotNil(t, deletion.PurgedAt)
+		assert.Equal(t, []string{"cus_1"}, provider.deleted)
+		assert.Empty(t, store.deletions)
+	})
+
+	t.Run("should erase personal data, detach payment methods and emit an audit event", func(t *testing.T) {
+		lifecycle, store, provider, publisher := setup(time.Hour)
+
+		erasure, err := lifecycle.Erase(ctx, "acme", "cus_1")
+		require.NoError(t, err)
+		assert.Equal(t, 2, erasure.PaymentMethodsDetached)
+		assert.Equal(t, []string{"pm_1", "pm_2"}, provider.detached)
+		assert.Contains(t, store.erasures, "cus_1")
+		assert.ErrorIs(t, lifecycle.Check(ctx, "cus_1"), customers.ErrCustomerErased)
+
+		require.Len(t, publisher.events, 1)
+		assert.Equal(t, customers.EventErased, publisher.events[0].Type)
+		assert.Equal(t, "cus_1", publisher.events[0].Subject)
+	})
+
+	t.Run("should erase a purged customer without calling the provider", func(t *testing.T) {
+		lifecycle, store, provider, _ := setup(time.Hour)
+		_, err := lifecycle.Delete(ctx, "acme", "cus_1")
+		require.NoError(t, err)
+		_, err = lifecycle.PurgeDue(ctx, time.Now().Add(2*time.Hour))
+		require.NoError(t, err)
+
+		erasure, err := lifecycle.Erase(ctx, "acme", "cus_1")
+		require.NoError(t, err)
+		assert.Zero(t, erasure.PaymentMethodsDetached)
+		assert.Empty(t, provider.detached)
+		assert.Contains(t, store.erasures, "cus_1")
+	})
+}
+
+// MockCustomerLifecycleStore keeps customer deletions and erasures in memory
+type MockCustomerLifecycleStore struct {
+	deletions map[string]*customers.Deletion
+	erasures  map[string]*customers.Erasure
+	forgotten []string
+}
+
+// NewMockCustomerLifecycleStore creates a store without deletions or erasures
+func NewMockCustomerLifecycleStore() *MockCustomerLifecycleStore {
+	return &MockCustomerLifecycleStore{
+		deletions: make(map[string]*customers.Deletion),
+		erasures:  make(map[string]*customers.Erasure),
+	}
+}
+
+func (m *MockCustomerLifecycleStore) CreateCustomerDeletion(ctx context.Context, deletion *customers.Deletion) (*customers.Deletion, error) {
+	if existing, ok := m.deletions[deletion.CustomerID]; ok {
+		return existing, nil
+	}
+	m.deletions[deletion.CustomerID] = deletion
+	return deletion, nil
+}
+
+func (m *MockCustomerLifecycleStore) GetCustomerDeletion(ctx context.Context, customerID string) (*customers.Deletion, error) {
+	deletion, ok := m.deletions[customerID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return deletion, nil
+}
+
+func (m *MockCustomerLifecycleStore) DeleteCustomerDeletion(ctx context.Context, customerID string) (bool, error) {
+	deletion, ok := m.deletions[customerID]
+	if !ok || deletion.PurgedAt != nil {
+		return false, nil
+	}
+	delete(m.deletions, customerID)
+	return true, nil
+}
+
+func (m *MockCustomerLifecycleStore) ListDueCustomerDeletions(ctx context.Context, now time.Time, limit int) ([]*customers.Deletion, error) {
+	var due []*customers.Deletion
+	for _, deletion := range m.deletions {
+		if deletion.PurgedAt == nil && !deletion.PurgeAfter.After(now) && len(due) < limit {
+			due = append(due, deletion)
+		}
+	}
+	return due, nil
+}
+
+func (m *MockCustomerLifecycleStore) ClaimCustomerDeletion(ctx context.Context, customerID string, purgedAt time.Time) (*customers.Deletion, error) {
+	deletion, ok := m.deletions[customerID]
+	if !ok || deletion.PurgedAt != nil {
+		return nil, sql.ErrNoRows
+	}
+	deletion.PurgedAt = &purgedAt
+	return deletion, nil
+}
+
+func (m *MockCustomerLifecycleStore) ReleaseCustomerDeletion(ctx context.Context, customerID string) error {
+	if deletion, ok := m.deletions[customerID]; ok {
+		deletion.PurgedAt = nil
+	}
+	return nil
+}
+
+func (m *MockCustomerLifecycleStore) EraseCustomer(ctx context.Context, erasure *customers.Erasure) (*customers.Erasure, error) {
+	if existing, ok := m.erasures[erasure.CustomerID]; ok {
+		return existing, nil
+	}
+	m.erasures[erasure.CustomerID] = erasure
+	return erasure, nil
+}
+
+func (m *MockCustomerLifecycleStore) GetCustomerErasure(ctx context.Context, customerID string) (*customers.Erasure, error) {
+	erasure, ok := m.erasures[customerID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return erasure, nil
+}
+
+func (m *MockCustomerLifecycleStore) DeleteCustomerIdentity(ctx context.Context, customerID string) error {
+	m.forgotten = append(m.forgotten, customerID)
+	return nil
+}
+
+func (m *MockCustomerLifecycleStore) DeleteCustomerReferences(ctx context.Context, customerID string) error {
+	return nil
+}
+
+// MockCustomerLifecycleProvider holds provider customers and their payment
+// methods, recording deletions and detachments
+type MockCustomerLifecycleProvider struct {
+	customers      map[string]bool
+	paymentMethods map[string][]string
+	deleteErr      map[string]error
+	deleted        []string
+	detached       []string
+}
+
+func (m *MockCustomerLifecycleProvider) GetCustomer(ctx context.Context, customerID string) (*stripe.Customer, error) {
+	if !m.customers[customerID] {
+		return nil, errors.New("no such customer")
+	}
+	return &stripe.Customer{ID: customerID}, nil
+}
+
+func (m *MockCustomerLifecycleProvider) DeleteCustomer(ctx context.Context, customerID string) error {
+	if err := m.deleteErr[customerID]; err != nil {
+		return err
+	}
+	m.deleted = append(m.deleted, customerID)
+	delete(m.customers, customerID)
+	return nil
+}
+
+func (m *MockCustomerLifecycleProvider) ListPaymentMethods(ctx context.Context, customerID string, page stripe.Page) ([]*stripe.PaymentMethod, error) {
+	var paymentMethods []*stripe.PaymentMethod
+	for _, id := range m.paymentMethods[customerID] {
+		paymentMethods = append(paymentMethods, &stripe.PaymentMethod{ID: id})
+	}
+	return paymentMethods, nil
+}
+
+func (m *MockCustomerLifecycleProvider) DetachPaymentMethod(ctx context.Context, paymentMethodID string) error {
+	m.detached = append(m.detached, paymentMethodID)
+	return nil
+}
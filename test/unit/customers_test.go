@@ -0,0 +1,192 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"apis/payments/services/customers"
+	"apis/payments/services/events"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCustomerIdentities tests duplicate detection and email verification
+func TestCustomerIdentities(t *testing.T) {
+	setup := func(verification bool) (*customers.Service, *MockCustomerIdentityStore, *MockEventPublisher) {
+		store := NewMockCustomerIdentityStore()
+		publisher := &MockEventPublisher{}
+		source, err := events.NewSource("/payments")
+		require.NoError(t, err)
+		config := &customers.Config{
+			NameSimilarity:      0.85,
+			VerificationEnabled: verification,
+			VerificationTTL:     time.Hour,
+		}
+		return customers.NewService(store, events.NewEmitter(source, publisher), config), store, publisher
+	}
+
+	t.Run("should normalize emails to their mailbox", func(t *testing.T) {
+		assert.Equal(t, "jane@example.com", customers.NormalizeEmail(" Jane+Billing@Example.com "))
+		assert.Equal(t, "janedoe@gmail.com", customers.NormalizeEmail("Jane.Doe+shop@googlemail.com"))
+		assert.Equal(t, "jane.doe@example.com", customers.NormalizeEmail("jane.doe@example.com"))
+	})
+
+	t.Run("should match names regardless of order, case and small typos", func(t *testing.T) {
+		assert.Equal(t, float64(1), customers.NameSimilarity("Doe, Jane", "jane doe"))
+		assert.GreaterOrEqual(t, customers.NameSimilarity("Jane Doe", "Jane Doo"), 0.85)
+		assert.Less(t, customers.NameSimilarity("Jane Doe", "John Smith"), 0.85)
+	})
+
+	t.Run("should reject a duplicate within the tenant", func(t *testing.T) {
+		service, _, _ := setup(false)
+		_, err := service.Register(context.Background(), "acme", "cus_1", "jane@example.com", "Jane Doe")
+		require.NoError(t, err)
+
+		err = service.CheckDuplicate(context.Background(), "acme", "JANE+2@example.com", "Doe Jane")
+		var duplicate *customers.DuplicateError
+		require.True(t, errors.As(err, &duplicate))
+		assert.Equal(t, "cus_1", duplicate.Existing.CustomerID)
+		assert.ErrorIs(t, err, customers.ErrDuplicateCustomer)
+	})
+
+	t.Run("should allow the same email with a different name or tenant", func(t *testing.T) {
+		service, _, _ := setup(false)
+		_, err := service.Register(context.Background(), "acme", "cus_1", "jane@example.com", "Jane Doe")
+		require.NoError(t, err)
+
+		assert.NoError(t, service.CheckDuplicate(context.Background(), "acme", "jane@example.com", "Accounts Payable"))
+		assert.NoError(t, service.CheckDuplicate(context.Background(), "globex", "jane@example.com", "Jane Doe"))
+	})
+
+	t.Run("should issue and emit a verification token when enabled", func(t *testing.T) {
+		service, store, publisher := setup(true)
+
+		_, err := service.Register(context.Background(), "acme", "cus_1", "jane@example.com", "Jane Doe")
+		require.NoError(t, err)
+		assert.NotEmpty(t, store.identities["cus_1"].VerificationTokenHash)
+		require.Len(t, publisher.events, 1)
+		assert.Equal(t, customers.EventVerificationRequested, publisher.events[0].Type)
+	})
+
+	t.Run("should verify an email with the outstanding token only", func(t *testing.T) {
+		service, _, _ := setup(false)
+		_, err := service.Register(context.Background(), "acme", "cus_1", "jane@example.com", "Jane Doe")
+		require.NoError(t, err)
+
+		first, err := service.RequestVerification(context.Background(), "cus_1", "jane@example.com")
+		require.NoError(t, err)
+		second, err := service.RequestVerification(context.Background(), "cus_1", "jane@example.com")
+		require.NoError(t, err)
+
+		_, err = service.VerifyEmail(context.Background(), "cus_1", first.Token)
+		assert.ErrorIs(t, err, customers.ErrInvalidVerificationToken)
+
+		identity, err := service.VerifyEmail(context.Background(), "cus_1", second.Token)
+		require.NoError(t, err)
+		assert.True(t, identity.EmailVerified)
+
+		_, err = service.RequestVerification(context.Background(), "cus_1", "jane@example.com")
+		assert.ErrorIs(t, err, customers.ErrAlreadyVerified)
+	})
+
+	t.Run("should reject an expired token", func(t *testing.T) {
+		service, store, _ := setup(false)
+		_, err := service.Register(context.Background(), "acme", "cus_1", "jane@example.com", "Jane Doe")
+		require.NoError(t, err)
+
+		verification, err := service.RequestVerification(context.Background(), "cus_1", "jane@example.com")
+		require.NoError(t, err)
+		expired := time.Now().Add(-time.Minute)
+		store.identities["cus_1"].VerificationExpiresAt = &expired
+
+		_, err = service.VerifyEmail(context.Background(), "cus_1", verification.Token)
+		assert.ErrorIs(t, err, customers.ErrInvalidVerificationToken)
+	})
+
+	t.Run("should clear verification when the email changes", func(t *testing.T) {
+		service, _, _ := setup(false)
+		_, err := service.Register(context.Background(), "acme", "cus_1", "jane@example.com", "Jane Doe")
+		require.NoError(t, err)
+		verification, err := service.RequestVerification(context.Background(), "cus_1", "jane@example.com")
+		require.NoError(t, err)
+		_, err = service.VerifyEmail(context.Background(), "cus_1", verification.Token)
+		require.NoError(t, err)
+
+		identity, err := service.Update(context.Background(), "cus_1", "Jane+x@example.com", "Jane Doe")
+		require.NoError(t, err)
+		assert.True(t, identity.EmailVerified)
+
+		identity, err = service.Update(context.Background(), "cus_1", "jane@example.org", "Jane Doe")
+		require.NoError(t, err)
+		assert.False(t, identity.EmailVerified)
+	})
+}
+
+// MockCustomerIdentityStore keeps customer identities in memory
+type MockCustomerIdentityStore struct {
+	identities map[string]*customers.Identity
+}
+
+// NewMockCustomerIdentityStore creates an empty customer identity store
+func NewMockCustomerIdentityStore() *MockCustomerIdentityStore {
+	return &MockCustomerIdentityStore{identities: make(map[string]*customers.Identity)}
+}
+
+func (m *MockCustomerIdentityStore) CreateCustomerIdentity(ctx context.Context, identity *customers.Identity) (*customers.Identity, error) {
+	identity.CreatedAt = time.Now()
+	m.identities[identity.CustomerID] = identity
+	return identity, nil
+}
+
+func (m *MockCustomerIdentityStore) GetCustomerIdentity(ctx context.Context, customerID string) (*customers.Identity, error) {
+	identity, ok := m.identities[customerID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *identity
+	return &copied, nil
+}
+
+func (m *MockCustomerIdentityStore) ListCustomerIdentitiesByEmail(ctx context.Context, tenantID, email string) ([]*customers.Identity, error) {
+	var identities []*customers.Identity
+	for _, identity := range m.identities {
+		if identity.TenantID == tenantID && identity.Email == email {
+			identities = append(identities, identity)
+		}
+	}
+	return identities, nil
+}
+
+func (m *MockCustomerIdentityStore) UpdateCustomerIdentity(ctx context.Context, customerID, email, name string, verifiedAt *time.Time) (*customers.Identity, error) {
+	identity := m.identities[customerID]
+	identity.Email = email
+	identity.Name = name
+	identity.EmailVerifiedAt = verifiedAt
+	identity.EmailVerified = verifiedAt != nil
+	return identity, nil
+}
+
+func (m *MockCustomerIdentityStore) SetCustomerVerificationToken(ctx context.Context, customerID, tokenHash string, expiresAt time.Time) error {
+	identity := m.identities[customerID]
+	identity.VerificationTokenHash = tokenHash
+	identity.VerificationExpiresAt = &expiresAt
+	return nil
+}
+
+func (m *MockCustomerIdentityStore) MarkCustomerEmailVerified(ctx context.Context, customerID string, verifiedAt time.Time) (*customers.Identity, error) {
+	identity := m.identities[customerID]
+	identity.EmailVerified = true
+	identity.EmailVerifiedAt = &verifiedAt
+	identity.VerificationTokenHash = ""
+	identity.VerificationExpiresAt = nil
+	return identity, nil
+}
+
+func (m *MockCustomerIdentityStore) DeleteCustomerIdentity(ctx context.Context, customerID string) error {
+	delete(m.identities, customerID)
+	return nil
+}
@@ -0,0 +1,96 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"apis/payments/services/customers"
+	"apis/payments/services/stripe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCustomerSearch tests listing a tenant's customers with filters and
+// searching them for typeahead
+func TestCustomerSearch(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should normalize filters before listing", func(t *testing.T) {
+		store := &MockCustomerSearchStore{customers: []*stripe.Customer{{ID: "cus_1"}}}
+		searcher := customers.NewSearcher(store)
+
+		found, err := searcher.List(ctx, customers.Filter{
+			TenantID:   "acme",
+			Email:      " Ada@Example.com ",
+			NamePrefix: "Ada_100%",
+			Metadata:   map[string]string{"plan": "pro"},
+			Limit:      11,
+		})
+		require.NoError(t, err)
+		assert.Len(t, found, 1)
+
+		assert.Equal(t, "ada@example.com", store.filter.Email)
+		assert.Equal(t, `ada\_100\%`, store.filter.NamePrefix, "LIKE wildcards only match themselves")
+		assert.Equal(t, map[string]string{"plan": "pro"}, store.filter.Metadata)
+		assert.Equal(t, 11, store.filter.Limit)
+	})
+
+	t.Run("should reject a created range that ends before it starts", func(t *testing.T) {
+		store := &MockCustomerSearchStore{}
+		after := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+		before := after.Add(-time.Hour)
+
+		_, err := customers.NewSearcher(store).List(ctx, customers.Filter{TenantID: "acme", CreatedAfter: &after, CreatedBefore: &before})
+		assert.ErrorIs(t, err, customers.ErrInvalidCreatedRange)
+		assert.Equal(t, 0, store.calls)
+	})
+
+	t.Run("should search by ID and lowercased prefix", func(t *testing.T) {
+		store := &MockCustomerSearchStore{}
+		searcher := customers.NewSearcher(store)
+
+		_, err := searcher.Search(ctx, "acme", " Cus_ABC ", 0)
+		require.NoError(t, err)
+		assert.Equal(t, "Cus_ABC", store.customerID)
+		assert.Equal(t, `cus\_abc`, store.prefix)
+		assert.Equal(t, customers.DefaultSearchResults, store.limit)
+
+		_, err = searcher.Search(ctx, "acme", "ada", 500)
+		require.NoError(t, err)
+		assert.Equal(t, customers.MaxSearchResults, store.limit)
+	})
+
+	t.Run("should require a search query", func(t *testing.T) {
+		store := &MockCustomerSearchStore{}
+
+		_, err := customers.NewSearcher(store).Search(ctx, "acme", "  ", 5)
+		assert.ErrorIs(t, err, customers.ErrSearchQueryRequired)
+		assert.Equal(t, 0, store.calls)
+	})
+}
+
+// MockCustomerSearchStore returns customers, recording the arguments of the
+// last listing or search
+type MockCustomerSearchStore struct {
+	customers  []*stripe.Customer
+	calls      int
+	filter     customers.Filter
+	tenantID   string
+	customerID string
+	prefix     string
+	limit      int
+}
+
+func (m *MockCustomerSearchStore) ListCustomers(ctx context.Context, filter customers.Filter) ([]*stripe.Customer, error) {
+	m.calls++
+	m.filter = filter
+	return m.customers, nil
+}
+
+func (m *MockCustomerSearchStore) SearchCustomers(ctx context.Context, tenantID, customerID, prefix string, limit int) ([]*stripe.Customer, error) {
+	m.calls++
+	m.tenantID, m.customerID, m.prefix, m.limit = tenantID, customerID, prefix, limit
+	return m.customers, nil
+}
@@ -0,0 +1,161 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"apis/payments/services/customerstats"
+	"apis/payments/services/stripe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// TestCustomerStats tests computing cached customer statistics from local
+// charge aggregates
+func TestCustomerStats(t *testing.T) {
+	first := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	last := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	setup := func() (*customerstats.Service, *MockCustomerStatsStore, *stripe.WebhookService) {
+		store := NewMockCustomerStatsStore()
+		store.stats["cus_1"] = []*customerstats.CurrencyStats{
+			{Currency: "usd", ChargeCount: 5, SucceededCount: 4, RefundedCount: 1, DisputeCount: 1, GrossAmount: 10000, RefundedAmount: 2500, FirstChargeAt: first, LastChargeAt: last.AddDate(0, -1, 0)},
+			{Currency: "eur", ChargeCount: 1, SucceededCount: 1, GrossAmount: 3000, FirstChargeAt: first.AddDate(0, 1, 0), LastChargeAt: last},
+		}
+		store.chargeCustomers["ch_1"] = "cus_1"
+
+		service := customerstats.NewService(store, &customerstats.Config{CacheTTL: time.Minute, CacheSize: 10})
+		webhooks := stripe.NewWebhookService("whsec_test")
+		service.RegisterWebhookHandlers(webhooks)
+		return service, store, webhooks
+	}
+
+	t.Run("should derive lifetime value, average order value and refund rate", func(t *testing.T) {
+		service, _, _ := setup()
+
+		stats, err := service.Get(context.Background(), "cus_1", 0)
+		require.NoError(t, err)
+		assert.Equal(t, int64(6), stats.ChargeCount)
+		assert.Equal(t, int64(5), stats.SucceededCount)
+		assert.Equal(t, int64(1), stats.DisputeCount)
+		assert.InDelta(t, 0.2, stats.RefundRate, 1e-9)
+		assert.Equal(t, first, *stats.FirstChargeAt)
+		assert.Equal(t, last, *stats.LastChargeAt)
+
+		require.Len(t, stats.Currencies, 2)
+		assert.Equal(t, int64(7500), stats.Currencies[0].LifetimeValue)
+		assert.Equal(t, int64(2500), stats.Currencies[0].AverageOrderValue)
+		assert.Equal(t, int64(3000), stats.Currencies[1].LifetimeValue)
+	})
+
+	t.Run("should return empty stats for a customer without charges", func(t *testing.T) {
+		service, _, _ := setup()
+
+		stats, err := service.Get(context.Background(), "cus_2", 0)
+		require.NoError(t, err)
+		assert.Zero(t, stats.ChargeCount)
+		assert.Zero(t, stats.RefundRate)
+		assert.Nil(t, stats.FirstChargeAt)
+	})
+
+	t.Run("should query charges created within the window", func(t *testing.T) {
+		service, store, _ := setup()
+
+		_, err := service.Get(context.Background(), "cus_1", 30)
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().AddDate(0, 0, -30), store.from, time.Minute)
+
+		_, err = service.Get(context.Background(), "cus_1", 0)
+		require.NoError(t, err)
+		assert.True(t, store.from.IsZero())
+	})
+
+	t.Run("should reject windows out of range", func(t *testing.T) {
+		service, _, _ := setup()
+
+		_, err := service.Get(context.Background(), "cus_1", -1)
+		assert.ErrorIs(t, err, customerstats.ErrInvalidWindow)
+		_, err = service.Get(context.Background(), "cus_1", customerstats.MaxWindowDays+1)
+		assert.ErrorIs(t, err, customerstats.ErrInvalidWindow)
+	})
+
+	t.Run("should cache stats until a charge event for the customer", func(t *testing.T) {
+		service, store, webhooks := setup()
+
+		_, err := service.Get(context.Background(), "cus_1", 0)
+		require.NoError(t, err)
+		_, err = service.Get(context.Background(), "cus_1", 0)
+		require.NoError(t, err)
+		assert.Equal(t, 1, store.queries)
+
+		require.NoError(t, webhooks.Dispatch(context.Background(), stripego.Event{
+			Type: stripego.EventTypeChargeRefunded,
+			Data: &stripego.EventData{Raw: []byte(`{"id": "ch_2", "customer": {"id": "cus_1"}}`)},
+		}))
+
+		_, err = service.Get(context.Background(), "cus_1", 0)
+		require.NoError(t, err)
+		assert.Equal(t, 2, store.queries)
+	})
+
+	t.Run("should drop the charge customer's stats on dispute events", func(t *testing.T) {
+		service, store, webhooks := setup()
+
+		_, err := service.Get(context.Background(), "cus_1", 0)
+		require.NoError(t, err)
+
+		require.NoError(t, webhooks.Dispatch(context.Background(), stripego.Event{
+			Type: stripego.EventTypeChargeDisputeCreated,
+			Data: &stripego.EventData{Raw: []byte(`{"id": "dp_1", "charge": {"id": "ch_1"}}`)},
+		}))
+		require.NoError(t, webhooks.Dispatch(context.Background(), stripego.Event{
+			Type: stripego.EventTypeChargeDisputeCreated,
+			Data: &stripego.EventData{Raw: []byte(`{"id": "dp_2", "charge": {"id": "ch_unknown"}}`)},
+		}))
+
+		_, err = service.Get(context.Background(), "cus_1", 0)
+		require.NoError(t, err)
+		assert.Equal(t, 2, store.queries)
+	})
+}
+
+// MockCustomerStatsStore is a mock implementation of customerstats.Store
+type MockCustomerStatsStore struct {
+	stats           map[string][]*customerstats.CurrencyStats
+	chargeCustomers map[string]string
+	from            time.Time
+	queries         int
+}
+
+// NewMockCustomerStatsStore creates a new mock customer stats store
+func NewMockCustomerStatsStore() *MockCustomerStatsStore {
+	return &MockCustomerStatsStore{
+		stats:           make(map[string][]*customerstats.CurrencyStats),
+		chargeCustomers: make(map[string]string),
+	}
+}
+
+func (m *MockCustomerStatsStore) GetCustomerChargeStats(ctx context.Context, customerID string, from time.Time) ([]*customerstats.CurrencyStats, error) {
+	m.from = from
+	m.queries++
+
+	// Copies, since the service fills in derived values
+	var stats []*customerstats.CurrencyStats
+	for _, currency := range m.stats[customerID] {
+		copied := *currency
+		stats = append(stats, &copied)
+	}
+	return stats, nil
+}
+
+func (m *MockCustomerStatsStore) GetChargeCustomer(ctx context.Context, chargeID string) (string, error) {
+	customerID, ok := m.chargeCustomers[chargeID]
+	if !ok {
+		return "", sql.ErrNoRows
+	}
+	return customerID, nil
+}
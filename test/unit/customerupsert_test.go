@@ -0,0 +1,205 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"apis/payments/services/customers"
+	"apis/payments/services/events"
+	"apis/payments/services/stripe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCustomerUpsert tests creating or updating customers keyed by an
+// external reference without duplicating them
+func TestCustomerUpsert(t *testing.T) {
+	setup := func() (*customers.Upserter, *MockCustomerReferenceStore, *MockCustomerProvider, *MockCustomerIdentityStore) {
+		references := NewMockCustomerReferenceStore()
+		provider := NewMockCustomerProvider()
+		identityStore := NewMockCustomerIdentityStore()
+		source, err := events.NewSource("/payments")
+		require.NoError(t, err)
+		identities := customers.NewService(identityStore, events.NewEmitter(source, &MockEventPublisher{}), &customers.Config{NameSimilarity: 0.85})
+		return customers.NewUpserter(references, provider, identities), references, provider, identityStore
+	}
+
+	t.Run("should create the customer and then update it", func(t *testing.T) {
+		upserter, references, provider, identities := setup()
+
+		customer, created, err := upserter.Upsert(context.Background(), "tenant_1", "user_42", &stripe.CustomerRequest{Email: "jane@example.com", Name: "Jane Doe"})
+		require.NoError(t, err)
+		assert.True(t, created)
+		assert.Equal(t, "user_42", customer.Metadata[customers.ExternalReferenceMetadataKey])
+		assert.Equal(t, customer.ID, references.references["tenant_1/user_42"].CustomerID)
+		assert.Contains(t, identities.identities, customer.ID)
+
+		updated, created, err := upserter.Upsert(context.Background(), "tenant_1", "user_42", &stripe.CustomerRequest{Email: "jane@example.com", Name: "Jane Smith"})
+		require.NoError(t, err)
+		assert.False(t, created)
+		assert.Equal(t, customer.ID, updated.ID)
+		assert.Equal(t, "Jane Smith", updated.Name)
+		assert.Len(t, provider.customers, 1)
+	})
+
+	t.Run("should key creations by tenant and reference", func(t *testing.T) {
+		upserter, _, provider, _ := setup()
+
+		_, _, err := upserter.Upsert(context.Background(), "tenant_1", "user_42", &stripe.CustomerRequest{Email: "jane@example.com"})
+		require.NoError(t, err)
+		_, _, err = upserter.Upsert(context.Background(), "tenant_2", "user_42", &stripe.CustomerRequest{Email: "jane@example.com"})
+		require.NoError(t, err)
+
+		require.Len(t, provider.idempotencyKeys, 2)
+		assert.True(t, strings.HasPrefix(provider.idempotencyKeys[0], "customer-upsert-"))
+		assert.NotEqual(t, provider.idempotencyKeys[0], provider.idempotencyKeys[1])
+	})
+
+	t.Run("should delete its customer and update the winner after losing a race", func(t *testing.T) {
+		upserter, references, provider, _ := setup()
+		provider.customers["cus_winner"] = &stripe.Customer{ID: "cus_winner"}
+		references.raceWinner = &customers.Reference{TenantID: "tenant_1", ExternalReference: "user_42", CustomerID: "cus_winner"}
+
+		customer, created, err := upserter.Upsert(context.Background(), "tenant_1", "user_42", &stripe.CustomerRequest{Name: "Jane Doe"})
+		require.NoError(t, err)
+		assert.False(t, created)
+		assert.Equal(t, "cus_winner", customer.ID)
+		assert.Equal(t, "Jane Doe", customer.Name)
+		assert.Len(t, provider.deleted, 1)
+		assert.Len(t, provider.customers, 1)
+	})
+
+	t.Run("should update the winner when a racing creation is rejected", func(t *testing.T) {
+		upserter, references, provider, _ := setup()
+		provider.customers["cus_winner"] = &stripe.Customer{ID: "cus_winner"}
+		provider.createErr = errors.New("idempotency key reused with different parameters")
+		provider.onCreate = func() {
+			references.references["tenant_1/user_42"] = &customers.Reference{TenantID: "tenant_1", ExternalReference: "user_42", CustomerID: "cus_winner"}
+		}
+
+		customer, created, err := upserter.Upsert(context.Background(), "tenant_1", "user_42", &stripe.CustomerRequest{Name: "Jane Doe"})
+		require.NoError(t, err)
+		assert.False(t, created)
+		assert.Equal(t, "cus_winner", customer.ID)
+	})
+
+	t.Run("should reject missing or overlong references", func(t *testing.T) {
+		upserter, _, provider, _ := setup()
+
+		_, _, err := upserter.Upsert(context.Background(), "tenant_1", "", &stripe.CustomerRequest{})
+		assert.ErrorIs(t, err, customers.ErrInvalidExternalReference)
+
+		_, _, err = upserter.Upsert(context.Background(), "tenant_1", strings.Repeat("x", 256), &stripe.CustomerRequest{})
+		assert.ErrorIs(t, err, customers.ErrInvalidExternalReference)
+		assert.Empty(t, provider.customers)
+	})
+
+	t.Run("should forget the references of a deleted customer", func(t *testing.T) {
+		upserter, references, _, _ := setup()
+
+		customer, _, err := upserter.Upsert(context.Background(), "tenant_1", "user_42", &stripe.CustomerRequest{})
+		require.NoError(t, err)
+
+		require.NoError(t, upserter.Forget(context.Background(), customer.ID))
+		assert.Empty(t, references.references)
+	})
+}
+
+// MockCustomerReferenceStore keeps external references in memory. A race
+// winner, when set, claims the reference just before it is created.
+type MockCustomerReferenceStore struct {
+	references map[string]*customers.Reference
+	raceWinner *customers.Reference
+}
+
+// NewMockCustomerReferenceStore creates an empty customer reference store
+func NewMockCustomerReferenceStore() *MockCustomerReferenceStore {
+	return &MockCustomerReferenceStore{references: make(map[string]*customers.Reference)}
+}
+
+func (m *MockCustomerReferenceStore) CreateCustomerReference(ctx context.Context, reference *customers.Reference) (*customers.Reference, error) {
+	key := reference.TenantID + "/" + reference.ExternalReference
+	if m.raceWinner != nil {
+		m.references[key] = m.raceWinner
+		m.raceWinner = nil
+	}
+	if _, ok := m.references[key]; ok {
+		return nil, fmt.Errorf("failed to create customer reference: %w", sql.ErrNoRows)
+	}
+	reference.CreatedAt = time.Now()
+	m.references[key] = reference
+	return reference, nil
+}
+
+func (m *MockCustomerReferenceStore) GetCustomerReference(ctx context.Context, tenantID, externalReference string) (*customers.Reference, error) {
+	reference, ok := m.references[tenantID+"/"+externalReference]
+	if !ok {
+		return nil, fmt.Errorf("failed to get customer reference: %w", sql.ErrNoRows)
+	}
+	return reference, nil
+}
+
+func (m *MockCustomerReferenceStore) DeleteCustomerReferences(ctx context.Context, customerID string) error {
+	for key, reference := range m.references {
+		if reference.CustomerID == customerID {
+			delete(m.references, key)
+		}
+	}
+	return nil
+}
+
+// MockCustomerProvider keeps provider customers in memory and records the
+// idempotency keys of creations
+type MockCustomerProvider struct {
+	customers       map[string]*stripe.Customer
+	idempotencyKeys []string
+	deleted         []string
+	createErr       error
+	onCreate        func()
+}
+
+// NewMockCustomerProvider creates a provider without customers
+func NewMockCustomerProvider() *MockCustomerProvider {
+	return &MockCustomerProvider{customers: make(map[string]*stripe.Customer)}
+}
+
+func (m *MockCustomerProvider) CreateCustomer(ctx context.Context, request *stripe.CustomerRequest) (*stripe.Customer, error) {
+	m.idempotencyKeys = append(m.idempotencyKeys, request.IdempotencyKey)
+	if m.onCreate != nil {
+		m.onCreate()
+	}
+	if m.createErr != nil {
+		return nil, m.createErr
+	}
+	customer := &stripe.Customer{
+		ID:       fmt.Sprintf("cus_%d", len(m.idempotencyKeys)+len(m.customers)),
+		Email:    request.Email,
+		Name:     request.Name,
+		Metadata: request.Metadata,
+	}
+	m.customers[customer.ID] = customer
+	return customer, nil
+}
+
+func (m *MockCustomerProvider) UpdateCustomer(ctx context.Context, customerID string, request *stripe.CustomerRequest) (*stripe.Customer, error) {
+	customer, ok := m.customers[customerID]
+	if !ok {
+		return nil, errors.New("no such customer")
+	}
+	customer.Email = request.Email
+	customer.Name = request.Name
+	customer.Metadata = request.Metadata
+	return customer, nil
+}
+
+func (m *MockCustomerProvider) DeleteCustomer(ctx context.Context, customerID string) error {
+	m.deleted = append(m.deleted, customerID)
+	delete(m.customers, customerID)
+	return nil
+}
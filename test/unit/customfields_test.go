@@ -0,0 +1,166 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"apis/payments/services/customfields"
+	"apis/payments/services/stripe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// TestCustomFields tests collecting tenant custom fields and printing them on receipts and invoices
+func TestCustomFields(t *testing.T) {
+	purchaseFields := func() []*customfields.Field {
+		return []*customfields.Field{
+			{Key: "po_number", Label: "PO number", Required: true, Pattern: "^PO-[0-9]+$"},
+			{Key: "cost_center", Label: "Cost center", Enum: []string{"R&D", "Sales"}},
+		}
+	}
+
+	t.Run("should validate and render values in field order", func(t *testing.T) {
+		service := customfields.NewService(NewMockCustomFieldStore(), NewMockInvoiceLabeller())
+		_, err := service.Define(context.Background(), "tenant_1", purchaseFields())
+		require.NoError(t, err)
+
+		rendered, err := service.Collect(context.Background(), "tenant_1", map[string]string{
+			"cost_center": "R&D",
+			"po_number":   "PO-1234",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []customfields.Value{
+			{Key: "po_number", Label: "PO number", Value: "PO-1234"},
+			{Key: "cost_center", Label: "Cost center", Value: "R&D"},
+		}, rendered)
+
+		assert.Equal(t, "Order 42 (PO number: PO-1234; Cost center: R&D)", customfields.ReceiptDescription("Order 42", rendered))
+		assert.Equal(t, "PO number: PO-1234; Cost center: R&D", customfields.ReceiptDescription("", rendered))
+	})
+
+	t.Run("should report every invalid value", func(t *testing.T) {
+		service := customfields.NewService(NewMockCustomFieldStore(), NewMockInvoiceLabeller())
+		_, err := service.Define(context.Background(), "tenant_1", purchaseFields())
+		require.NoError(t, err)
+
+		_, err = service.Collect(context.Background(), "tenant_1", map[string]string{
+			"cost_center": "Marketing",
+			"project":     "apollo",
+		})
+		require.ErrorIs(t, err, customfields.ErrInvalidCustomFields)
+		assert.Contains(t, err.Error(), "po_number is required")
+		assert.Contains(t, err.Error(), "cost_center must be one of R&D, Sales")
+		assert.Contains(t, err.Error(), "project is not a defined field")
+
+		_, err = service.Collect(context.Background(), "tenant_1", map[string]string{"po_number": "1234"})
+		assert.ErrorIs(t, err, customfields.ErrInvalidCustomFields)
+	})
+
+	t.Run("should reject values when the tenant defines no fields", func(t *testing.T) {
+		service := customfields.NewService(NewMockCustomFieldStore(), NewMockInvoiceLabeller())
+
+		rendered, err := service.Collect(context.Background(), "tenant_1", nil)
+		assert.NoError(t, err)
+		assert.Empty(t, rendered)
+
+		_, err = service.Collect(context.Background(), "tenant_1", map[string]string{"po_number": "PO-1"})
+		assert.ErrorIs(t, err, customfields.ErrInvalidCustomFields)
+	})
+
+	t.Run("should reject definitions providers cannot print", func(t *testing.T) {
+		service := customfields.NewService(NewMockCustomFieldStore(), NewMockInvoiceLabeller())
+
+		tooMany := append(purchaseFields(),
+			&customfields.Field{Key: "project", Label: "Project"},
+			&customfields.Field{Key: "department", Label: "Department"},
+			&customfields.Field{Key: "region", Label: "Region"},
+		)
+		_, err := service.Define(context.Background(), "tenant_1", tooMany)
+		assert.ErrorIs(t, err, customfields.ErrInvalidDefinition)
+
+		duplicate := append(purchaseFields(), &customfields.Field{Key: "po_number", Label: "PO"})
+		_, err = service.Define(context.Background(), "tenant_1", duplicate)
+		assert.ErrorIs(t, err, customfields.ErrInvalidDefinition)
+
+		_, err = service.Define(context.Background(), "tenant_1", []*customfields.Field{{Key: "PO Number", Label: "PO number"}})
+		assert.ErrorIs(t, err, customfields.ErrInvalidDefinition)
+
+		_, err = service.Define(context.Background(), "tenant_1", []*customfields.Field{{Key: "po_number", Label: "PO number", MaxLength: 500}})
+		assert.ErrorIs(t, err, customfields.ErrInvalidDefinition)
+	})
+
+	t.Run("should store values in prefixed metadata keys", func(t *testing.T) {
+		metadata := customfields.ToMetadata(map[string]string{"order_id": "ord_1"}, map[string]string{"po_number": "PO-1"})
+		assert.Equal(t, map[string]string{"order_id": "ord_1", "cf_po_number": "PO-1"}, metadata)
+		assert.Equal(t, map[string]string{"po_number": "PO-1"}, customfields.FromMetadata(metadata))
+	})
+
+	t.Run("should print subscription values on draft invoices", func(t *testing.T) {
+		labeller := NewMockInvoiceLabeller()
+		service := customfields.NewService(NewMockCustomFieldStore(), labeller)
+		_, err := service.Define(context.Background(), "tenant_1", purchaseFields())
+		require.NoError(t, err)
+
+		invoice := &stripego.Invoice{
+			ID:     "in_123",
+			Status: stripego.InvoiceStatusDraft,
+			SubscriptionDetails: &stripego.InvoiceSubscriptionDetails{
+				Metadata: map[string]string{"tenant_id": "tenant_1", "cf_po_number": "PO-1234"},
+			},
+		}
+		require.NoError(t, service.LabelInvoice(context.Background(), invoice))
+		assert.Equal(t, []stripe.InvoiceCustomField{{Name: "PO number", Value: "PO-1234"}}, labeller.fields["in_123"])
+
+		invoice.ID = "in_456"
+		invoice.Status = stripego.InvoiceStatusOpen
+		require.NoError(t, service.LabelInvoice(context.Background(), invoice))
+		assert.NotContains(t, labeller.fields, "in_456")
+	})
+}
+
+// MockCustomFieldStore keeps custom field definitions in memory
+type MockCustomFieldStore struct {
+	definitions map[string]*customfields.Definition
+}
+
+// NewMockCustomFieldStore creates an empty custom field store
+func NewMockCustomFieldStore() *MockCustomFieldStore {
+	return &MockCustomFieldStore{definitions: make(map[string]*customfields.Definition)}
+}
+
+func (m *MockCustomFieldStore) GetCustomFieldDefinition(ctx context.Context, tenantID string) (*customfields.Definition, error) {
+	definition, ok := m.definitions[tenantID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return definition, nil
+}
+
+func (m *MockCustomFieldStore) UpsertCustomFieldDefinition(ctx context.Context, definition *customfields.Definition) (*customfields.Definition, error) {
+	m.definitions[definition.TenantID] = definition
+	return definition, nil
+}
+
+func (m *MockCustomFieldStore) DeleteCustomFieldDefinition(ctx context.Context, tenantID string) (bool, error) {
+	_, ok := m.definitions[tenantID]
+	delete(m.definitions, tenantID)
+	return ok, nil
+}
+
+// MockInvoiceLabeller records the custom fields set on invoices
+type MockInvoiceLabeller struct {
+	fields map[string][]stripe.InvoiceCustomField
+}
+
+// NewMockInvoiceLabeller creates an invoice labeller with no invoices labelled
+func NewMockInvoiceLabeller() *MockInvoiceLabeller {
+	return &MockInvoiceLabeller{fields: make(map[string][]stripe.InvoiceCustomField)}
+}
+
+func (m *MockInvoiceLabeller) SetInvoiceCustomFields(ctx context.Context, invoiceID string, fields []stripe.InvoiceCustomField) (*stripe.Invoice, error) {
+	m.fields[invoiceID] = fields
+	return &stripe.Invoice{ID: invoiceID, CustomFields: fields}, nil
+}
@@ -0,0 +1,282 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"apis/payments/services/deadletter"
+	"apis/payments/services/kafka"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDeadLetters tests keeping failed events in the dead-letter queue and
+// retrying them with exponential backoff
+func TestDeadLetters(t *testing.T) {
+	config := func() *deadletter.Config {
+		return &deadletter.Config{
+			RetryEnabled: true,
+			Interval:     time.Minute,
+			MaxAttempts:  3,
+			Backoff:      time.Minute,
+			MaxBackoff:   time.Hour,
+			BatchSize:    10,
+		}
+	}
+
+	setup := func() (*deadletter.Service, *MockDeadLetterStore, *MockDeadLetterReplayer) {
+		store := NewMockDeadLetterStore()
+		replayer := &MockDeadLetterReplayer{}
+		service := deadletter.NewService(store, config())
+		service.Register(deadletter.SourceStripeWebhook, replayer)
+		return service, store, replayer
+	}
+
+	// due makes every pending entry due for retry
+	due := func(store *MockDeadLetterStore) {
+		past := time.Now().Add(-time.Second)
+		for _, entry := range store.entries {
+			if entry.NextAttemptAt != nil {
+				entry.NextAttemptAt = &past
+			}
+		}
+	}
+
+	t.Run("should record failures for retry after the first backoff", func(t *testing.T) {
+		service, store, _ := setup()
+
+		entry, err := service.Record(context.Background(), deadletter.SourceStripeWebhook, "evt_123", []byte(`{"id": "evt_123"}`), nil, errors.New("handler failed"))
+		require.NoError(t, err)
+
+		assert.Equal(t, deadletter.StatusPending, entry.Status)
+		assert.Equal(t, "handler failed", entry.LastError)
+		require.NotNil(t, entry.NextAttemptAt)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), *entry.NextAttemptAt, 5*time.Second)
+
+		// Not due yet
+		result, err := service.RetryDue(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 0, result.Attempted)
+
+		_, err = service.Record(context.Background(), deadletter.SourceStripeWebhook, "evt_123", []byte(`{"id": "evt_123"}`), nil, errors.New("failed again"))
+		require.NoError(t, err)
+		assert.Len(t, store.entries, 1)
+	})
+
+	t.Run("should resolve entries whose replay succeeds", func(t *testing.T) {
+		service, store, replayer := setup()
+
+		_, err := service.Record(context.Background(), deadletter.SourceStripeWebhook, "evt_123", []byte(`{}`), nil, errors.New("handler failed"))
+		require.NoError(t, err)
+		due(store)
+
+		result, err := service.RetryDue(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, deadletter.RetryResult{Attempted: 1, Resolved: 1}, result)
+		assert.Equal(t, 1, replayer.calls)
+
+		entry := store.entries["evt_123"]
+		assert.Equal(t, deadletter.StatusResolved, entry.Status)
+		assert.NotNil(t, entry.ResolvedAt)
+		assert.Nil(t, entry.NextAttemptAt)
+	})
+
+	t.Run("should back off exponentially until attempts run out", func(t *testing.T) {
+		service, store, replayer := setup()
+		replayer.err = errors.New("still failing")
+
+		_, err := service.Record(context.Background(), deadletter.SourceStripeWebhook, "evt_123", []byte(`{}`), nil, errors.New("handler failed"))
+		require.NoError(t, err)
+
+		for _, delay := range []time.Duration{2 * time.Minute, 4 * time.Minute} {
+			due(store)
+			result, err := service.RetryDue(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, 1, result.Failed)
+
+			entry := store.entries["evt_123"]
+			assert.Equal(t, deadletter.StatusPending, entry.Status)
+			assert.WithinDuration(t, time.Now().Add(delay), *entry.NextAttemptAt, 5*time.Second)
+		}
+
+		due(store)
+		result, err := service.RetryDue(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Exhausted)
+
+		entry := store.entries["evt_123"]
+		assert.Equal(t, deadletter.StatusExhausted, entry.Status)
+		assert.Equal(t, 3, entry.Attempts)
+		assert.Equal(t, "still failing", entry.LastError)
+		assert.Nil(t, entry.NextAttemptAt)
+	})
+
+	t.Run("should retry exhausted entries manually but not resolved ones", func(t *testing.T) {
+		service, store, replayer := setup()
+
+		entry, err := service.Record(context.Background(), deadletter.SourceStripeWebhook, "evt_123", []byte(`{}`), nil, errors.New("handler failed"))
+		require.NoError(t, err)
+		store.entries["evt_123"].Status = deadletter.StatusExhausted
+
+		replayer.err = errors.New("still failing")
+		retried, err := service.Retry(context.Background(), entry.ID)
+		require.NoError(t, err)
+		assert.Equal(t, deadletter.StatusExhausted, retried.Status)
+
+		replayer.err = nil
+		retried, err = service.Retry(context.Background(), entry.ID)
+		require.NoError(t, err)
+		assert.Equal(t, deadletter.StatusResolved, retried.Status)
+
+		_, err = service.Retry(context.Background(), entry.ID)
+		assert.ErrorIs(t, err, deadletter.ErrAlreadyResolved)
+
+		_, err = service.Retry(context.Background(), "dlq_missing")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("should not replay sources without a replayer", func(t *testing.T) {
+		service, _, _ := setup()
+
+		entry, err := service.Record(context.Background(), deadletter.SourceKafkaCommand, "payment-commands/0/7", []byte(`{}`), nil, errors.New("handler failed"))
+		require.NoError(t, err)
+
+		_, err = service.Retry(context.Background(), entry.ID)
+		assert.ErrorIs(t, err, deadletter.ErrNoReplayer)
+	})
+
+	t.Run("should only purge resolved or exhausted entries", func(t *testing.T) {
+		service, store, _ := setup()
+
+		_, err := service.Purge(context.Background(), deadletter.StatusPending, time.Time{})
+		assert.ErrorIs(t, err, deadletter.ErrInvalidPurge)
+
+		_, err = service.Purge(context.Background(), deadletter.StatusResolved, time.Time{})
+		require.NoError(t, err)
+		assert.Equal(t, deadletter.StatusResolved, store.purgedStatus)
+		assert.WithinDuration(t, time.Now(), store.purgedBefore, 5*time.Second)
+	})
+
+	t.Run("should record messages the Kafka consumer dead-letters", func(t *testing.T) {
+		producer := &MockSyncProducer{}
+		recorder := &MockKafkaRecorder{}
+		consumer := kafka.NewConsumerWithClients(&kafka.Config{
+			DLQTopic:    "payment-commands.dlq",
+			MaxAttempts: 2,
+			Backoff:     time.Millisecond,
+			MaxBackoff:  time.Millisecond,
+		}, nil, producer, kafka.HandlerFunc(func(ctx context.Context, message *kafka.Message) error {
+			return errors.New("temporarily unavailable")
+		}))
+		consumer.UseRecorder(recorder)
+
+		claim := make(chan *sarama.ConsumerMessage, 1)
+		claim <- &sarama.ConsumerMessage{Topic: "payment-commands", Partition: 1, Offset: 9, Value: []byte(`{"id": "cmd_1"}`)}
+		close(claim)
+		require.NoError(t, consumer.ConsumeClaim(NewMockConsumerGroupSession(), &MockConsumerGroupClaim{messages: claim}))
+
+		require.Len(t, producer.sent, 1)
+		require.Len(t, recorder.messages, 1)
+		assert.Equal(t, int64(9), recorder.messages[0].Offset)
+		assert.Equal(t, 2, recorder.attempts[0])
+	})
+}
+
+// MockDeadLetterStore keeps dead-letter entries in memory, keyed by their key
+type MockDeadLetterStore struct {
+	entries      map[string]*deadletter.Entry
+	purgedStatus string
+	purgedBefore time.Time
+}
+
+// NewMockDeadLetterStore creates an empty dead-letter store
+func NewMockDeadLetterStore() *MockDeadLetterStore {
+	return &MockDeadLetterStore{entries: map[string]*deadletter.Entry{}}
+}
+
+func (m *MockDeadLetterStore) CreateDeadLetter(ctx context.Context, entry *deadletter.Entry) (*deadletter.Entry, error) {
+	if existing, ok := m.entries[entry.Key]; ok {
+		existing.LastError = entry.LastError
+		return existing, nil
+	}
+	m.entries[entry.Key] = entry
+	return entry, nil
+}
+
+func (m *MockDeadLetterStore) GetDeadLetter(ctx context.Context, id string) (*deadletter.Entry, error) {
+	for _, entry := range m.entries {
+		if entry.ID == id {
+			return entry, nil
+		}
+	}
+	return nil, fmt.Errorf("failed to get dead letter: %w", sql.ErrNoRows)
+}
+
+func (m *MockDeadLetterStore) ListDeadLetters(ctx context.Context, filter deadletter.Filter) ([]*deadletter.Entry, error) {
+	var entries []*deadletter.Entry
+	for _, entry := range m.entries {
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func (m *MockDeadLetterStore) ClaimDueDeadLetters(ctx context.Context, leaseUntil time.Time, limit int) ([]*deadletter.Entry, error) {
+	var entries []*deadletter.Entry
+	for _, entry := range m.entries {
+		if entry.Status == deadletter.StatusPending && entry.NextAttemptAt != nil && !entry.NextAttemptAt.After(time.Now()) {
+			entry.NextAttemptAt = &leaseUntil
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func (m *MockDeadLetterStore) UpdateDeadLetter(ctx context.Context, entry *deadletter.Entry) (*deadletter.Entry, error) {
+	m.entries[entry.Key] = entry
+	return entry, nil
+}
+
+func (m *MockDeadLetterStore) DeleteDeadLetter(ctx context.Context, id string) (bool, error) {
+	for key, entry := range m.entries {
+		if entry.ID == id {
+			delete(m.entries, key)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *MockDeadLetterStore) PurgeDeadLetters(ctx context.Context, status string, before time.Time) (int64, error) {
+	m.purgedStatus = status
+	m.purgedBefore = before
+	return 0, nil
+}
+
+// MockDeadLetterReplayer counts replays, failing them while err is set
+type MockDeadLetterReplayer struct {
+	calls int
+	err   error
+}
+
+func (m *MockDeadLetterReplayer) Replay(ctx context.Context, entry *deadletter.Entry) error {
+	m.calls++
+	return m.err
+}
+
+// MockKafkaRecorder records dead-lettered Kafka messages
+type MockKafkaRecorder struct {
+	messages []*kafka.Message
+	attempts []int
+}
+
+func (m *MockKafkaRecorder) RecordDeadLetter(ctx context.Context, message *kafka.Message, cause error, attempts int) error {
+	m.messages = append(m.messages, message)
+	m.attempts = append(m.attempts, attempts)
+	return nil
+}
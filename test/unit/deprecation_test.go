@@ -0,0 +1,119 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"apis/payments/services/deprecation"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDeprecation tests deprecation headers and usage reporting
+func TestDeprecation(t *testing.T) {
+	ctx := context.Background()
+	notice := deprecation.Notice{
+		ID:         "charges.create",
+		Surface:    "POST /api/v1/charges",
+		Deprecated: time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC),
+		Sunset:     time.Date(2027, time.October, 1, 0, 0, 0, 0, time.UTC),
+		Link:       "https://example.com/migrate",
+	}
+
+	t.Run("should format Deprecation, Sunset and Link headers", func(t *testing.T) {
+		headers := notice.Headers()
+		assert.Equal(t, "@1792108800", headers["Deprecation"])
+		assert.Equal(t, "Fri, 01 Oct 2027 00:00:00 GMT", headers["Sunset"])
+		assert.Equal(t, `<https://example.com/migrate>; rel="deprecation"; type="text/html"`, headers["Link"])
+	})
+
+	t.Run("should omit Sunset and Link when not set", func(t *testing.T) {
+		headers := deprecation.Notice{ID: "x", Deprecated: notice.Deprecated}.Headers()
+		assert.NotContains(t, headers, "Sunset")
+		assert.NotContains(t, headers, "Link")
+	})
+
+	t.Run("should report usage per API key", func(t *testing.T) {
+		store := NewMockDeprecationStore()
+		service := deprecation.NewService(store)
+		service.Register(notice)
+		service.Register(deprecation.Notice{ID: "payment_methods.type_sofort"})
+
+		service.Track("charges.create", "key_a")
+		service.Track("charges.create", "key_a")
+		service.Track("charges.create", "key_b")
+
+		report, err := service.Report(ctx)
+		require.NoError(t, err)
+		require.Len(t, report, 2)
+		assert.Equal(t, "charges.create", report[0].Notice.ID)
+		assert.Equal(t, int64(3), report[0].TotalCount)
+		assert.Len(t, report[0].Callers, 2)
+		assert.Equal(t, "payment_methods.type_sofort", report[1].Notice.ID)
+		assert.Equal(t, int64(0), report[1].TotalCount)
+	})
+
+	t.Run("should add flushed usage to stored totals", func(t *testing.T) {
+		store := NewMockDeprecationStore()
+		service := deprecation.NewService(store)
+		service.Register(notice)
+
+		service.Track("charges.create", "key_a")
+		require.NoError(t, service.Flush(ctx))
+		service.Track("charges.create", "key_a")
+		require.NoError(t, service.Flush(ctx))
+
+		assert.Equal(t, int64(2), store.usage["charges.create/key_a"].Count)
+	})
+
+	t.Run("should keep usage that failed to flush", func(t *testing.T) {
+		store := NewMockDeprecationStore()
+		service := deprecation.NewService(store)
+		service.Register(notice)
+		service.Track("charges.create", "key_a")
+
+		store.err = errors.New("database unavailable")
+		assert.Error(t, service.Flush(ctx))
+
+		store.err = nil
+		service.Track("charges.create", "key_a")
+		require.NoError(t, service.Flush(ctx))
+		assert.Equal(t, int64(2), store.usage["charges.create/key_a"].Count)
+	})
+}
+
+// MockDeprecationStore is an in-memory deprecation.Store
+type MockDeprecationStore struct {
+	usage map[string]*deprecation.Usage
+	err   error
+}
+
+func NewMockDeprecationStore() *MockDeprecationStore {
+	return &MockDeprecationStore{usage: make(map[string]*deprecation.Usage)}
+}
+
+func (m *MockDeprecationStore) RecordDeprecatedUsage(ctx context.Context, usage *deprecation.Usage) error {
+	if m.err != nil {
+		return m.err
+	}
+	key := usage.NoticeID + "/" + usage.APIKeyID
+	if existing, ok := m.usage[key]; ok {
+		existing.Count += usage.Count
+		existing.LastSeen = usage.LastSeen
+		return nil
+	}
+	stored := *usage
+	m.usage[key] = &stored
+	return nil
+}
+
+func (m *MockDeprecationStore) ListDeprecatedUsage(ctx context.Context) ([]*deprecation.Usage, error) {
+	usages := make([]*deprecation.Usage, 0, len(m.usage))
+	for _, usage := range m.usage {
+		usages = append(usages, usage)
+	}
+	return usages, nil
+}
@@ -0,0 +1,257 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"apis/payments/services/disputes"
+	"apis/payments/services/events"
+	"apis/payments/services/stripe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDisputeEvidence tests staging, uploading and submitting dispute
+// evidence and reminding about deadlines
+func TestDisputeEvidence(t *testing.T) {
+	ctx := context.Background()
+	pdf := []byte("%PDF-1.4\n1 0 obj\n<<>>\nendobj\n")
+
+	setup := func(status string, dueIn time.Duration) (*disputes.EvidenceService, *MockDisputeStore, *MockEvidenceStore, *MockEvidenceProvider, *MockEventPublisher) {
+		store := NewMockDisputeStore()
+		dueBy := time.Now().Add(dueIn).UTC()
+		dispute := &stripe.Dispute{
+			ID:              "dp_1",
+			ChargeID:        "ch_1",
+			Amount:          2000,
+			Currency:        "usd",
+			Status:          status,
+			EvidenceDetails: &stripe.DisputeEvidenceDetails{DueBy: &dueBy},
+		}
+		require.NoError(t, store.UpsertDispute(ctx, dispute, time.Now().Add(-time.Hour)))
+
+		evidenceStore := NewMockEvidenceStore()
+		provider := &MockEvidenceProvider{}
+		publisher := &MockEventPublisher{}
+		source, err := events.NewSource("/payments")
+		require.NoError(t, err)
+		service := disputes.NewEvidenceService(evidenceStore, disputes.NewService(store, &MockDisputeProvider{}), provider, events.NewEmitter(source, publisher), &disputes.Config{
+			ReminderLead:     72 * time.Hour,
+			ReminderInterval: time.Hour,
+			MaxFileSize:      1024,
+		})
+		evidenceStore.disputes = store
+		return service, store, evidenceStore, provider, publisher
+	}
+
+	t.Run("should stage text fields and remove emptied ones", func(t *testing.T) {
+		service, _, _, _, _ := setup("needs_response", 48*time.Hour)
+
+		evidence, err := service.Update(ctx, "dp_1", map[string]string{"customer_name": " Jane ", "product_description": "Shoes"}, "op_1")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"customer_name": "Jane", "product_description": "Shoes"}, evidence.Fields)
+		assert.NotNil(t, evidence.DueBy)
+
+		evidence, err = service.Update(ctx, "dp_1", map[string]string{"product_description": ""}, "op_1")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"customer_name": "Jane"}, evidence.Fields)
+
+		_, err = service.Update(ctx, "dp_1", map[string]string{"favourite_colour": "blue"}, "op_1")
+		assert.ErrorIs(t, err, disputes.ErrInvalidEvidence)
+		_, err = service.Update(ctx, "dp_1", map[string]string{"receipt": "file_unknown"}, "op_1")
+		assert.ErrorIs(t, err, disputes.ErrInvalidEvidence, "file fields only take files uploaded for the dispute")
+		_, err = service.Update(ctx, "dp_1", map[string]string{"uncategorized_text": strings.Repeat("a", 150001)}, "op_1")
+		assert.ErrorIs(t, err, disputes.ErrInvalidEvidence)
+	})
+
+	t.Run("should upload files and attach them to file fields", func(t *testing.T) {
+		service, _, _, provider, _ := setup("needs_response", 48*time.Hour)
+
+		file, err := service.Upload(ctx, "dp_1", &disputes.Upload{Field: "receipt", Filename: "../../receipt.pdf", Content: bytes.NewReader(pdf), UploadedBy: "op_1"})
+		require.NoError(t, err)
+		assert.Equal(t, "application/pdf", file.ContentType)
+		assert.Equal(t, "receipt.pdf", file.Filename)
+		assert.Equal(t, "file_1", file.ProviderFileID)
+		assert.Equal(t, string(pdf), string(provider.uploaded[0]))
+
+		other, err := service.Upload(ctx, "dp_1", &disputes.Upload{Filename: "log.pdf", Content: bytes.NewReader(pdf)})
+		require.NoError(t, err)
+		evidence, err := service.Update(ctx, "dp_1", map[string]string{"uncategorized_file": other.ID}, "op_1")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"receipt": "file_1", "uncategorized_file": "file_2"}, evidence.Fields)
+		assert.Len(t, evidence.Files, 2)
+
+		_, err = service.Upload(ctx, "dp_1", &disputes.Upload{Filename: "notes.txt", Content: strings.NewReader("plain text")})
+		assert.ErrorIs(t, err, disputes.ErrInvalidEvidence)
+		_, err = service.Upload(ctx, "dp_1", &disputes.Upload{Filename: "big.pdf", Content: bytes.NewReader(append(pdf, make([]byte, 1024)...))})
+		assert.ErrorIs(t, err, disputes.ErrInvalidEvidence)
+		_, err = service.Upload(ctx, "dp_1", &disputes.Upload{Field: "customer_name", Filename: "a.pdf", Content: bytes.NewReader(pdf)})
+		assert.ErrorIs(t, err, disputes.ErrInvalidEvidence)
+		assert.Len(t, provider.uploaded, 2)
+	})
+
+	t.Run("should submit staged evidence and sync the dispute", func(t *testing.T) {
+		service, store, _, provider, _ := setup("needs_response", 48*time.Hour)
+
+		_, err := service.Submit(ctx, "dp_1", "op_1")
+		assert.ErrorIs(t, err, disputes.ErrNoEvidence)
+
+		_, err = service.Update(ctx, "dp_1", map[string]string{"uncategorized_text": "Delivered"}, "op_1")
+		require.NoError(t, err)
+		evidence, err := service.Submit(ctx, "dp_1", "op_2")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"uncategorized_text": "Delivered"}, provider.submitted)
+		assert.Equal(t, "under_review", evidence.Status)
+		assert.Equal(t, "op_2", evidence.SubmittedBy)
+		assert.NotNil(t, evidence.SubmittedAt)
+		assert.Equal(t, "under_review", store.disputes["dp_1"].Status)
+
+		_, err = service.Update(ctx, "dp_1", map[string]string{"uncategorized_text": "More"}, "op_1")
+		assert.ErrorIs(t, err, disputes.ErrDisputeClosed)
+	})
+
+	t.Run("should accept only disputes still awaiting a response", func(t *testing.T) {
+		service, store, _, provider, _ := setup("needs_response", 48*time.Hour)
+
+		dispute, err := service.Accept(ctx, "dp_1", "op_1")
+		require.NoError(t, err)
+		assert.Equal(t, "lost", dispute.Status)
+		assert.Equal(t, []string{"dp_1"}, provider.closed)
+		assert.Equal(t, "lost", store.disputes["dp_1"].Status)
+
+		_, err = service.Accept(ctx, "dp_1", "op_1")
+		assert.ErrorIs(t, err, disputes.ErrDisputeClosed)
+		assert.Len(t, provider.closed, 1)
+	})
+
+	t.Run("should refuse evidence past the deadline", func(t *testing.T) {
+		service, _, _, _, _ := setup("needs_response", -time.Hour)
+
+		_, err := service.Update(ctx, "dp_1", map[string]string{"customer_name": "Jane"}, "op_1")
+		assert.ErrorIs(t, err, disputes.ErrEvidencePastDue)
+	})
+
+	t.Run("should remind once per deadline within the lead", func(t *testing.T) {
+		service, _, evidenceStore, _, publisher := setup("warning_needs_response", 48*time.Hour)
+
+		sent, err := service.SendReminders(ctx, time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, 1, sent)
+		require.Len(t, publisher.events, 1)
+		assert.Equal(t, disputes.EventEvidenceDueSoon, publisher.events[0].Type)
+		assert.Equal(t, "dp_1", publisher.events[0].Subject)
+
+		sent, err = service.SendReminders(ctx, time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, 0, sent, "a deadline is reminded about once")
+		assert.Equal(t, 72*time.Hour, evidenceStore.lastWindow)
+	})
+}
+
+// MockEvidenceStore keeps staged evidence, files and reminders in memory
+type MockEvidenceStore struct {
+	disputes   *MockDisputeStore
+	evidence   map[string]*disputes.Evidence
+	files      map[string][]*disputes.EvidenceFile
+	reminders  map[string]bool
+	lastWindow time.Duration
+}
+
+// NewMockEvidenceStore creates an empty evidence store
+func NewMockEvidenceStore() *MockEvidenceStore {
+	return &MockEvidenceStore{
+		evidence:  make(map[string]*disputes.Evidence),
+		files:     make(map[string][]*disputes.EvidenceFile),
+		reminders: make(map[string]bool),
+	}
+}
+
+func (m *MockEvidenceStore) GetDisputeEvidence(ctx context.Context, disputeID string) (*disputes.Evidence, error) {
+	evidence, ok := m.evidence[disputeID]
+	if !ok {
+		return nil, fmt.Errorf("failed to get dispute evidence: %w", sql.ErrNoRows)
+	}
+	stored := *evidence
+	return &stored, nil
+}
+
+func (m *MockEvidenceStore) SaveDisputeEvidence(ctx context.Context, disputeID string, fields map[string]string, updatedBy string) (*disputes.Evidence, error) {
+	evidence, ok := m.evidence[disputeID]
+	if !ok {
+		evidence = &disputes.Evidence{DisputeID: disputeID}
+		m.evidence[disputeID] = evidence
+	}
+	evidence.Fields, evidence.UpdatedBy = fields, updatedBy
+	return evidence, nil
+}
+
+func (m *MockEvidenceStore) MarkDisputeEvidenceSubmitted(ctx context.Context, disputeID, submittedBy string) (*disputes.Evidence, error) {
+	evidence := m.evidence[disputeID]
+	now := time.Now()
+	evidence.SubmittedBy, evidence.SubmittedAt = submittedBy, &now
+	return evidence, nil
+}
+
+func (m *MockEvidenceStore) CreateDisputeEvidenceFile(ctx context.Context, file *disputes.EvidenceFile) (*disputes.EvidenceFile, error) {
+	m.files[file.DisputeID] = append(m.files[file.DisputeID], file)
+	return file, nil
+}
+
+func (m *MockEvidenceStore) ListDisputeEvidenceFiles(ctx context.Context, disputeID string) ([]*disputes.EvidenceFile, error) {
+	return m.files[disputeID], nil
+}
+
+func (m *MockEvidenceStore) ListDisputesDueForEvidence(ctx context.Context, dueAfter, dueBefore time.Time) ([]*stripe.Dispute, error) {
+	m.lastWindow = dueBefore.Sub(dueAfter)
+	var due []*stripe.Dispute
+	for _, dispute := range m.disputes.disputes {
+		dueBy := dispute.EvidenceDetails.DueBy
+		if dueBy != nil && dueBy.After(dueAfter) && !dueBy.After(dueBefore) {
+			due = append(due, dispute)
+		}
+	}
+	return due, nil
+}
+
+func (m *MockEvidenceStore) ClaimDisputeEvidenceReminder(ctx context.Context, disputeID string, dueBy time.Time) (bool, error) {
+	key := disputeID + dueBy.String()
+	if m.reminders[key] {
+		return false, nil
+	}
+	m.reminders[key] = true
+	return true, nil
+}
+
+// MockEvidenceProvider records uploaded files, submitted evidence and closed
+// disputes
+type MockEvidenceProvider struct {
+	uploaded  [][]byte
+	submitted map[string]string
+	closed    []string
+}
+
+func (m *MockEvidenceProvider) UploadDisputeEvidenceFile(ctx context.Context, filename string, content io.Reader) (*stripe.DisputeFile, error) {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return nil, err
+	}
+	m.uploaded = append(m.uploaded, data)
+	return &stripe.DisputeFile{ID: fmt.Sprintf("file_%d", len(m.uploaded)), Filename: filename, Size: int64(len(data))}, nil
+}
+
+func (m *MockEvidenceProvider) UpdateDisputeEvidence(ctx context.Context, disputeID string, evidence map[string]string, submit bool) (*stripe.Dispute, error) {
+	m.submitted = evidence
+	return &stripe.Dispute{ID: disputeID, ChargeID: "ch_1", Status: "under_review", EvidenceDetails: &stripe.DisputeEvidenceDetails{HasEvidence: true, SubmissionCount: 1}}, nil
+}
+
+func (m *MockEvidenceProvider) CloseDispute(ctx context.Context, disputeID string) (*stripe.Dispute, error) {
+	m.closed = append(m.closed, disputeID)
+	return &stripe.Dispute{ID: disputeID, ChargeID: "ch_1", Status: "lost"}, nil
+}
@@ -0,0 +1,151 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"apis/payments/services/disputes"
+	"apis/payments/services/stripe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// TestDisputes tests mapping disputes from the provider and keeping them in sync
+func TestDisputes(t *testing.T) {
+	t.Run("should map every dispute field from the provider", func(t *testing.T) {
+		dispute := stripe.ConvertDispute(&stripego.Dispute{
+			ID:                 "dp_1",
+			Amount:             2000,
+			Currency:           stripego.CurrencyUSD,
+			Charge:             &stripego.Charge{ID: "ch_1"},
+			PaymentIntent:      &stripego.PaymentIntent{ID: "pi_1"},
+			Reason:             stripego.DisputeReasonFraudulent,
+			Status:             stripego.DisputeStatusNeedsResponse,
+			NetworkReasonCode:  "10.4",
+			IsChargeRefundable: true,
+			Evidence: &stripego.DisputeEvidence{
+				CustomerName:    "Jane",
+				Receipt:         &stripego.File{ID: "file_1"},
+				ShippingCarrier: "UPS",
+			},
+			EvidenceDetails: &stripego.DisputeEvidenceDetails{
+				DueBy:           1700000000,
+				HasEvidence:     true,
+				SubmissionCount: 1,
+			},
+			PaymentMethodDetails: &stripego.DisputePaymentMethodDetails{
+				Type: stripego.DisputePaymentMethodDetailsTypeCard,
+				Card: &stripego.DisputePaymentMethodDetailsCard{Brand: "visa", NetworkReasonCode: "10.4"},
+			},
+			BalanceTransactions: []*stripego.BalanceTransaction{{ID: "txn_1"}},
+		})
+
+		assert.Equal(t, "ch_1", dispute.ChargeID)
+		assert.Equal(t, "pi_1", dispute.PaymentIntentID)
+		assert.Equal(t, "10.4", dispute.NetworkReasonCode)
+		assert.True(t, dispute.IsChargeRefundable)
+		assert.Equal(t, "Jane", dispute.Evidence.CustomerName)
+		assert.Equal(t, "file_1", dispute.Evidence.Receipt)
+		assert.Equal(t, "UPS", dispute.Evidence.ShippingCarrier)
+		require.NotNil(t, dispute.EvidenceDetails.DueBy)
+		assert.Equal(t, time.Unix(1700000000, 0).UTC(), *dispute.EvidenceDetails.DueBy)
+		assert.True(t, dispute.EvidenceDetails.HasEvidence)
+		assert.Equal(t, int64(1), dispute.EvidenceDetails.SubmissionCount)
+		assert.Equal(t, "visa", dispute.PaymentMethodDetails.CardBrand)
+		assert.Equal(t, []string{"txn_1"}, dispute.BalanceTransactions)
+	})
+
+	t.Run("should leave the due date empty when no response is allowed", func(t *testing.T) {
+		dispute := stripe.ConvertDispute(&stripego.Dispute{ID: "dp_1", EvidenceDetails: &stripego.DisputeEvidenceDetails{}})
+		assert.Nil(t, dispute.EvidenceDetails.DueBy)
+		assert.NotNil(t, dispute.Evidence)
+	})
+
+	t.Run("should fetch and store disputes not yet synced", func(t *testing.T) {
+		store := NewMockDisputeStore()
+		provider := &MockDisputeProvider{disputes: map[string]*stripe.Dispute{"dp_1": {ID: "dp_1", Status: "needs_response"}}}
+		service := disputes.NewService(store, provider)
+
+		dispute, err := service.Get(context.Background(), "dp_1")
+		require.NoError(t, err)
+		assert.Equal(t, "needs_response", dispute.Status)
+		assert.Contains(t, store.disputes, "dp_1")
+	})
+
+	t.Run("should ignore updates older than the stored dispute", func(t *testing.T) {
+		store := NewMockDisputeStore()
+		service := disputes.NewService(store, &MockDisputeProvider{})
+		now := time.Now()
+
+		require.NoError(t, service.Sync(context.Background(), &stripe.Dispute{ID: "dp_1", Status: "won"}, now))
+		require.NoError(t, service.Sync(context.Background(), &stripe.Dispute{ID: "dp_1", Status: "under_review"}, now.Add(-time.Minute)))
+
+		dispute, err := service.Get(context.Background(), "dp_1")
+		require.NoError(t, err)
+		assert.Equal(t, "won", dispute.Status)
+	})
+
+	t.Run("should clamp list limits", func(t *testing.T) {
+		store := NewMockDisputeStore()
+		service := disputes.NewService(store, &MockDisputeProvider{})
+
+		_, err := service.List(context.Background(), disputes.Filter{Limit: 10000})
+		require.NoError(t, err)
+		assert.Equal(t, 500, store.lastFilter.Limit)
+	})
+}
+
+// MockDisputeStore keeps disputes in memory
+type MockDisputeStore struct {
+	disputes   map[string]*stripe.Dispute
+	syncedAt   map[string]time.Time
+	lastFilter disputes.Filter
+}
+
+// NewMockDisputeStore creates an empty dispute store
+func NewMockDisputeStore() *MockDisputeStore {
+	return &MockDisputeStore{
+		disputes: make(map[string]*stripe.Dispute),
+		syncedAt: make(map[string]time.Time),
+	}
+}
+
+func (m *MockDisputeStore) UpsertDispute(ctx context.Context, dispute *stripe.Dispute, syncedAt time.Time) error {
+	if previous, ok := m.syncedAt[dispute.ID]; ok && syncedAt.Before(previous) {
+		return nil
+	}
+	m.disputes[dispute.ID] = dispute
+	m.syncedAt[dispute.ID] = syncedAt
+	return nil
+}
+
+func (m *MockDisputeStore) GetDispute(ctx context.Context, disputeID string) (*stripe.Dispute, error) {
+	dispute, ok := m.disputes[disputeID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return dispute, nil
+}
+
+func (m *MockDisputeStore) ListDisputes(ctx context.Context, filter disputes.Filter) ([]*stripe.Dispute, error) {
+	m.lastFilter = filter
+	return nil, nil
+}
+
+// MockDisputeProvider serves disputes from memory
+type MockDisputeProvider struct {
+	disputes map[string]*stripe.Dispute
+}
+
+func (m *MockDisputeProvider) GetDispute(ctx context.Context, disputeID string) (*stripe.Dispute, error) {
+	dispute, ok := m.disputes[disputeID]
+	if !ok {
+		return nil, errors.New("dispute not found")
+	}
+	return dispute, nil
+}
@@ -0,0 +1,83 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"apis/payments/services/drain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDrainTracker tests readiness and in-flight tracking during shutdown
+func TestDrainTracker(t *testing.T) {
+	t.Run("should flip readiness when draining starts", func(t *testing.T) {
+		tracker := drain.NewTracker()
+		assert.True(t, tracker.Ready())
+
+		tracker.StartDraining()
+		assert.False(t, tracker.Ready())
+	})
+
+	t.Run("should count in-flight work by kind", func(t *testing.T) {
+		tracker := drain.NewTracker()
+
+		doneRequest := tracker.Begin(drain.KindRequest)
+		doneWebhook := tracker.Begin(drain.KindWebhook)
+		assert.Equal(t, map[string]int{drain.KindRequest: 1, drain.KindWebhook: 1}, tracker.InFlight())
+
+		doneRequest()
+		doneRequest()
+		assert.Equal(t, map[string]int{drain.KindWebhook: 1}, tracker.InFlight())
+
+		doneWebhook()
+		assert.Empty(t, tracker.InFlight())
+	})
+
+	t.Run("should wait for in-flight work to finish", func(t *testing.T) {
+		tracker := drain.NewTracker()
+		done := tracker.Begin(drain.KindRequest)
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			done()
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		assert.NoError(t, tracker.Wait(ctx))
+	})
+
+	t.Run("should report abandoned work when the grace period expires", func(t *testing.T) {
+		tracker := drain.NewTracker()
+		tracker.Begin(drain.KindJob)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		err := tracker.Wait(ctx)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Contains(t, err.Error(), "job=1")
+	})
+
+	t.Run("should run every shutdown hook in order", func(t *testing.T) {
+		tracker := drain.NewTracker()
+		var ran []string
+		tracker.OnShutdown("consumer", func(ctx context.Context) error {
+			ran = append(ran, "consumer")
+			return errors.New("commit failed")
+		})
+		tracker.OnShutdown("publisher", func(ctx context.Context) error {
+			ran = append(ran, "publisher")
+			return nil
+		})
+
+		err := tracker.Shutdown(context.Background())
+		assert.ErrorContains(t, err, "consumer")
+		assert.Equal(t, []string{"consumer", "publisher"}, ran)
+	})
+}
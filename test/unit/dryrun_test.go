@@ -0,0 +1,102 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"apis/payments/services/dryrun"
+	"apis/payments/services/routing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDryRun tests reporting what a payment operation would do without
+// calling the provider
+func TestDryRun(t *testing.T) {
+	t.Run("should report created while every check passes", func(t *testing.T) {
+		result := dryrun.New(dryrun.OperationCharge)
+
+		assert.True(t, result.Check("metadata", nil))
+		assert.True(t, result.Check("budget", nil))
+
+		assert.True(t, result.DryRun)
+		assert.Equal(t, dryrun.OutcomeCreated, result.Outcome)
+		assert.Len(t, result.Checks, 2)
+	})
+
+	t.Run("should reject the operation on a failed check even if approval is required", func(t *testing.T) {
+		result := dryrun.New(dryrun.OperationRefund)
+
+		assert.False(t, result.Check("charge_state", errors.New("charge is not captured")))
+		result.RequireApproval()
+
+		assert.Equal(t, dryrun.OutcomeRejected, result.Outcome)
+		assert.Equal(t, dryrun.Check{Name: "charge_state", Error: "charge is not captured"}, result.Checks[0])
+	})
+
+	t.Run("should hold refunds exceeding limits for approval", func(t *testing.T) {
+		result := dryrun.New(dryrun.OperationRefund)
+		result.Check("refund_limits", nil)
+		result.RequireApproval()
+
+		assert.Equal(t, dryrun.OutcomePendingApproval, result.Outcome)
+	})
+
+	t.Run("should record currency routes as matched rules", func(t *testing.T) {
+		result := dryrun.New(dryrun.OperationCharge)
+		result.Route(&routing.Decision{Provider: "adyen", Currency: "eur", Reason: routing.ReasonCurrency})
+
+		assert.Equal(t, "adyen", result.Provider)
+		require.Len(t, result.RulesMatched, 1)
+		assert.Equal(t, "currency_route", result.RulesMatched[0].Name)
+
+		result = dryrun.New(dryrun.OperationCharge)
+		result.Route(&routing.Decision{Provider: "stripe", Currency: "usd", Reason: routing.ReasonDefault})
+		assert.Empty(t, result.RulesMatched)
+	})
+
+	t.Run("should check provider capabilities", func(t *testing.T) {
+		assert.NoError(t, dryrun.CheckCapabilities("stripe", dryrun.OperationCharge, 2000))
+		assert.ErrorIs(t, dryrun.CheckCapabilities("stripe", dryrun.OperationCharge, 10), dryrun.ErrAmountOutOfRange)
+		assert.ErrorIs(t, dryrun.CheckCapabilities("square", dryrun.OperationSubscription, 0), dryrun.ErrUnsupportedOperation)
+		assert.Error(t, dryrun.CheckCapabilities("adyen", dryrun.OperationCharge, 2000))
+	})
+
+	t.Run("should parse fee rates", func(t *testing.T) {
+		rate, err := dryrun.ParseFeeRate("2.9%+30")
+		require.NoError(t, err)
+		assert.Equal(t, dryrun.FeeRate{Percent: 2.9, Fixed: 30}, rate)
+
+		rate, err = dryrun.ParseFeeRate("1.4%")
+		require.NoError(t, err)
+		assert.Equal(t, dryrun.FeeRate{Percent: 1.4}, rate)
+
+		rate, err = dryrun.ParseFeeRate("25")
+		require.NoError(t, err)
+		assert.Equal(t, dryrun.FeeRate{Fixed: 25}, rate)
+
+		_, err = dryrun.ParseFeeRate("abc%")
+		assert.Error(t, err)
+	})
+
+	t.Run("should load fee rates over the Stripe default", func(t *testing.T) {
+		t.Setenv("PROVIDER_FEES", "paypal=3.49%+49, bogus=x")
+
+		config := dryrun.LoadConfig()
+		assert.Equal(t, dryrun.FeeRate{Percent: 2.9, Fixed: 30}, config.Fees["stripe"])
+		assert.Equal(t, dryrun.FeeRate{Percent: 3.49, Fixed: 49}, config.Fees["paypal"])
+		assert.NotContains(t, config.Fees, "bogus")
+	})
+
+	t.Run("should estimate fees for providers with a rate", func(t *testing.T) {
+		config := &dryrun.Config{Fees: map[string]dryrun.FeeRate{"stripe": {Percent: 2.9, Fixed: 30}}}
+
+		fee := config.EstimateFee("stripe", "usd", 2000)
+		require.NotNil(t, fee)
+		assert.Equal(t, int64(88), fee.Amount)
+		assert.Equal(t, "usd", fee.Currency)
+
+		assert.Nil(t, config.EstimateFee("adyen", "eur", 2000))
+	})
+}
@@ -0,0 +1,318 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"apis/payments/services/dunning"
+	"apis/payments/services/events"
+	"apis/payments/services/stripe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDunning tests retrying failed subscription payments and moving unpaid
+// subscriptions through the dunning states
+func TestDunning(t *testing.T) {
+	ctx := context.Background()
+	failedAt := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	failedInvoice := &stripe.Invoice{
+		ID:               "in_1",
+		CustomerID:       "cus_1",
+		SubscriptionID:   "sub_1",
+		Status:           "open",
+		CollectionMethod: "charge_automatically",
+		AmountRemaining:  2500,
+		Currency:         "usd",
+	}
+
+	setup := func() (*dunning.Service, *MockDunningStore, *MockDunningInvoices, *MockDunningSubscriptions, *MockEventPublisher) {
+		store := NewMockDunningStore()
+		invoices := &MockDunningInvoices{err: errors.New("card declined")}
+		subscriptions := &MockDunningSubscriptions{}
+		publisher := &MockEventPublisher{}
+		source, err := events.NewSource("/payments")
+		require.NoError(t, err)
+		service := dunning.NewService(store, invoices, subscriptions, events.NewEmitter(source, publisher), &dunning.Config{
+			Enabled:     true,
+			RetryDays:   []int{1, 3, 7},
+			GraceDays:   3,
+			SuspendDays: 14,
+			CancelDays:  30,
+			Interval:    time.Hour,
+			BatchSize:   100,
+		})
+		return service, store, invoices, subscriptions, publisher
+	}
+	eventTypes := func(publisher *MockEventPublisher) []string {
+		var types []string
+		for _, event := range publisher.events {
+			types = append(types, event.Type)
+		}
+		return types
+	}
+
+	t.Run("should open one case per failed subscription invoice", func(t *testing.T) {
+		service, _, _, _, publisher := setup()
+
+		opened, err := service.PaymentFailed(ctx, failedInvoice, failedAt)
+		require.NoError(t, err)
+		assert.Equal(t, dunning.StateGrace, opened.State)
+		assert.Equal(t, failedAt.Add(day), *opened.NextRetryAt)
+		assert.Equal(t, int64(2500), opened.AmountDue)
+
+		again, err := service.PaymentFailed(ctx, failedInvoice, failedAt.Add(time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, opened.ID, again.ID)
+		assert.Equal(t, []string{dunning.EventStarted}, eventTypes(publisher))
+		assert.Equal(t, "sub_1", publisher.events[0].Subject)
+
+		invoiced := *failedInvoice
+		invoiced.ID, invoiced.CollectionMethod = "in_2", "send_invoice"
+		ignored, err := service.PaymentFailed(ctx, &invoiced, failedAt)
+		require.NoError(t, err)
+		assert.Nil(t, ignored, "invoices sent for manual payment are not dunned")
+	})
+
+	t.Run("should retry on schedule and move through every state", func(t *testing.T) {
+		service, store, invoices, subscriptions, publisher := setup()
+		opened, err := service.PaymentFailed(ctx, failedInvoice, failedAt)
+		require.NoError(t, err)
+
+		_, err = service.Advance(ctx, failedAt.Add(12*time.Hour))
+		require.NoError(t, err)
+		assert.Zero(t, invoices.attempts, "no retry before day 1")
+
+		_, err = service.Advance(ctx, failedAt.Add(day))
+		require.NoError(t, err)
+		current := store.cases[opened.ID]
+		assert.Equal(t, 1, invoices.attempts)
+		assert.Equal(t, dunning.StateGrace, current.State)
+		assert.Equal(t, "card declined", current.LastError)
+		assert.Equal(t, failedAt.Add(3*day), *current.NextRetryAt)
+
+		_, err = service.Advance(ctx, failedAt.Add(3*day))
+		require.NoError(t, err)
+		assert.Equal(t, 2, invoices.attempts)
+		assert.Equal(t, dunning.StatePastDue, store.cases[opened.ID].State)
+
+		_, err = service.Advance(ctx, failedAt.Add(7*day))
+		require.NoError(t, err)
+		assert.Equal(t, 3, invoices.attempts)
+		assert.Nil(t, store.cases[opened.ID].NextRetryAt, "the schedule is used up")
+
+		_, err = service.Advance(ctx, failedAt.Add(14*day))
+		require.NoError(t, err)
+		assert.Equal(t, dunning.StateSuspended, store.cases[opened.ID].State)
+		assert.Equal(t, []string{"sub_1"}, subscriptions.paused)
+
+		_, err = service.Advance(ctx, failedAt.Add(30*day))
+		require.NoError(t, err)
+		current = store.cases[opened.ID]
+		assert.Equal(t, dunning.StateCanceled, current.State)
+		assert.NotNil(t, current.ResolvedAt)
+		assert.Equal(t, []string{"sub_1"}, subscriptions.canceled)
+		assert.Equal(t, 3, invoices.attempts)
+
+		assert.Equal(t, []string{
+			dunning.EventStarted,
+			dunning.EventRetryFailed,
+			dunning.EventRetryFailed,
+			dunning.EventPastDue,
+			dunning.EventRetryFailed,
+			dunning.EventSuspended,
+			dunning.EventCanceled,
+		}, eventTypes(publisher))
+	})
+
+	t.Run("should recover when a retry succeeds and restore suspended subscriptions", func(t *testing.T) {
+		service, store, invoices, subscriptions, publisher := setup()
+		opened, err := service.PaymentFailed(ctx, failedInvoice, failedAt)
+		require.NoError(t, err)
+		_, err = service.Advance(ctx, failedAt.Add(14*day))
+		require.NoError(t, err)
+		require.Equal(t, dunning.StateSuspended, store.cases[opened.ID].State)
+
+		invoices.err = nil
+		recovered, err := service.Retry(ctx, opened.ID)
+		require.NoError(t, err)
+		assert.Equal(t, dunning.StateRecovered, recovered.State)
+		assert.NotNil(t, recovered.ResolvedAt)
+		assert.Equal(t, []string{"sub_1"}, subscriptions.unpaused)
+		assert.Equal(t, dunning.EventRecovered, publisher.events[len(publisher.events)-1].Type)
+
+		_, err = service.Retry(ctx, opened.ID)
+		assert.ErrorIs(t, err, dunning.ErrCaseResolved)
+	})
+
+	t.Run("should retry a customer's open cases now", func(t *testing.T) {
+		service, store, invoices, _, _ := setup()
+		opened, err := service.PaymentFailed(ctx, failedInvoice, failedAt)
+		require.NoError(t, err)
+		other := *failedInvoice
+		other.ID, other.CustomerID = "in_2", "cus_2"
+		_, err = service.PaymentFailed(ctx, &other, failedAt)
+		require.NoError(t, err)
+
+		invoices.err = nil
+		retried, err := service.RetryCustomer(ctx, "cus_1")
+		require.NoError(t, err)
+		assert.Equal(t, 1, retried)
+		assert.Equal(t, 1, invoices.attempts, "other customers' cases aren't retried")
+		assert.Equal(t, dunning.StateRecovered, store.cases[opened.ID].State)
+
+		retried, err = service.RetryCustomer(ctx, "cus_1")
+		require.NoError(t, err)
+		assert.Zero(t, retried, "resolved cases aren't retried")
+	})
+
+	t.Run("should resolve cases from invoice webhooks", func(t *testing.T) {
+		service, store, _, _, _ := setup()
+		opened, err := service.PaymentFailed(ctx, failedInvoice, failedAt)
+		require.NoError(t, err)
+
+		require.NoError(t, service.InvoicePaid(ctx, "in_1", failedAt.Add(2*day)))
+		assert.Equal(t, dunning.StateRecovered, store.cases[opened.ID].State)
+
+		changed, err := service.Advance(ctx, failedAt.Add(30*day))
+		require.NoError(t, err)
+		assert.Zero(t, changed, "resolved cases are left alone")
+
+		voided := *failedInvoice
+		voided.ID = "in_3"
+		closing, err := service.PaymentFailed(ctx, &voided, failedAt)
+		require.NoError(t, err)
+		require.NoError(t, service.InvoiceClosed(ctx, "in_3", failedAt.Add(day)))
+		assert.Equal(t, dunning.StateClosed, store.cases[closing.ID].State)
+
+		assert.NoError(t, service.InvoicePaid(ctx, "in_unknown", failedAt), "invoices without a case are ignored")
+	})
+
+	t.Run("should do nothing when disabled", func(t *testing.T) {
+		store := NewMockDunningStore()
+		service := dunning.NewService(store, &MockDunningInvoices{}, &MockDunningSubscriptions{}, nil, &dunning.Config{})
+
+		opened, err := service.PaymentFailed(ctx, failedInvoice, failedAt)
+		require.NoError(t, err)
+		assert.Nil(t, opened)
+		assert.Empty(t, store.cases)
+	})
+
+	t.Run("should report unknown cases", func(t *testing.T) {
+		service, _, _, _, _ := setup()
+
+		_, err := service.Get(ctx, "dun_unknown")
+		assert.ErrorIs(t, err, dunning.ErrCaseNotFound)
+	})
+}
+
+// MockDunningStore keeps dunning cases in memory
+type MockDunningStore struct {
+	cases map[string]*dunning.Case
+}
+
+// NewMockDunningStore creates an empty dunning store
+func NewMockDunningStore() *MockDunningStore {
+	return &MockDunningStore{cases: make(map[string]*dunning.Case)}
+}
+
+func (m *MockDunningStore) OpenDunningCase(ctx context.Context, dunningCase *dunning.Case) (bool, error) {
+	if _, err := m.GetDunningCaseByInvoice(ctx, dunningCase.InvoiceID); err == nil {
+		return false, nil
+	}
+	stored := *dunningCase
+	m.cases[dunningCase.ID] = &stored
+	return true, nil
+}
+
+func (m *MockDunningStore) GetDunningCase(ctx context.Context, id string) (*dunning.Case, error) {
+	dunningCase, ok := m.cases[id]
+	if !ok {
+		return nil, fmt.Errorf("failed to get dunning case: %w", sql.ErrNoRows)
+	}
+	stored := *dunningCase
+	return &stored, nil
+}
+
+func (m *MockDunningStore) GetDunningCaseByInvoice(ctx context.Context, invoiceID string) (*dunning.Case, error) {
+	for id, dunningCase := range m.cases {
+		if dunningCase.InvoiceID == invoiceID {
+			return m.GetDunningCase(ctx, id)
+		}
+	}
+	return nil, fmt.Errorf("failed to get dunning case: %w", sql.ErrNoRows)
+}
+
+func (m *MockDunningStore) ListDunningCases(ctx context.Context, filter dunning.Filter) ([]*dunning.Case, error) {
+	var cases []*dunning.Case
+	for _, dunningCase := range m.cases {
+		if (filter.State == "" || dunningCase.State == filter.State) &&
+			(filter.CustomerID == "" || dunningCase.CustomerID == filter.CustomerID) {
+			cases = append(cases, dunningCase)
+		}
+	}
+	return cases, nil
+}
+
+func (m *MockDunningStore) ListOpenDunningCases(ctx context.Context, limit int) ([]*dunning.Case, error) {
+	var cases []*dunning.Case
+	for id, dunningCase := range m.cases {
+		if dunningCase.Open() {
+			stored, _ := m.GetDunningCase(ctx, id)
+			cases = append(cases, stored)
+		}
+	}
+	return cases, nil
+}
+
+func (m *MockDunningStore) UpdateDunningCase(ctx context.Context, dunningCase *dunning.Case) (*dunning.Case, error) {
+	current, ok := m.cases[dunningCase.ID]
+	if !ok || !current.Open() {
+		return nil, fmt.Errorf("failed to update dunning case: %w", sql.ErrNoRows)
+	}
+	stored := *dunningCase
+	m.cases[dunningCase.ID] = &stored
+	return m.GetDunningCase(ctx, dunningCase.ID)
+}
+
+// MockDunningInvoices fails or succeeds every invoice payment
+type MockDunningInvoices struct {
+	attempts int
+	err      error
+}
+
+func (m *MockDunningInvoices) PayInvoice(ctx context.Context, invoiceID, paymentMethodID string) (*stripe.Invoice, error) {
+	m.attempts++
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &stripe.Invoice{ID: invoiceID, Status: "paid"}, nil
+}
+
+// MockDunningSubscriptions records suspended, restored and canceled subscriptions
+type MockDunningSubscriptions struct {
+	paused   []string
+	unpaused []string
+	canceled []string
+}
+
+func (m *MockDunningSubscriptions) PauseSubscription(ctx context.Context, subscriptionID string) (*stripe.Subscription, error) {
+	m.paused = append(m.paused, subscriptionID)
+	return &stripe.Subscription{ID: subscriptionID, Paused: true}, nil
+}
+
+func (m *MockDunningSubscriptions) UnpauseSubscription(ctx context.Context, subscriptionID string) (*stripe.Subscription, error) {
+	m.unpaused = append(m.unpaused, subscriptionID)
+	return &stripe.Subscription{ID: subscriptionID}, nil
+}
+
+func (m *MockDunningSubscriptions) CancelSubscription(ctx context.Context, subscriptionID string, atPeriodEnd bool) (*stripe.Subscription, error) {
+	m.canceled = append(m.canceled, subscriptionID)
+	return &stripe.Subscription{ID: subscriptionID, Status: "canceled"}, nil
+}
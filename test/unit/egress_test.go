@@ -0,0 +1,65 @@
+package test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"apis/payments/services/egress"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEgressConfig tests loading per-provider egress settings
+func TestEgressConfig(t *testing.T) {
+	t.Run("should use default timeouts when nothing is configured", func(t *testing.T) {
+		config, err := egress.LoadConfig("stripe")
+		require.NoError(t, err)
+		assert.Nil(t, config.ProxyURL)
+		assert.Equal(t, egress.DefaultDialTimeout, config.DialTimeout)
+		assert.Equal(t, egress.DefaultRequestTimeout, config.RequestTimeout)
+	})
+
+	t.Run("should prefer provider settings over shared gateway settings", func(t *testing.T) {
+		t.Setenv("GATEWAY_EGRESS_PROXY_URL", "http://shared.internal:3128")
+		t.Setenv("STRIPE_EGRESS_PROXY_URL", "http://stripe.internal:3128")
+		t.Setenv("GATEWAY_DIAL_TIMEOUT_MS", "2500")
+
+		config, err := egress.LoadConfig("stripe")
+		require.NoError(t, err)
+		assert.Equal(t, "stripe.internal:3128", config.ProxyURL.Host)
+		assert.Equal(t, 2500*time.Millisecond, config.DialTimeout)
+
+		other, err := egress.LoadConfig("adyen")
+		require.NoError(t, err)
+		assert.Equal(t, "shared.internal:3128", other.ProxyURL.Host)
+	})
+
+	t.Run("should route requests through the configured proxy", func(t *testing.T) {
+		t.Setenv("STRIPE_EGRESS_PROXY_URL", "http://stripe.internal:3128")
+
+		config, err := egress.LoadConfig("stripe")
+		require.NoError(t, err)
+		transport, err := egress.NewTransport(config)
+		require.NoError(t, err)
+
+		request, _ := http.NewRequest(http.MethodGet, "https://api.stripe.com/v1/charges", nil)
+		proxyURL, err := transport.Proxy(request)
+		require.NoError(t, err)
+		assert.Equal(t, "stripe.internal:3128", proxyURL.Host)
+		assert.Equal(t, egress.DefaultTLSHandshakeTimeout, transport.TLSHandshakeTimeout)
+	})
+
+	t.Run("should reject invalid settings", func(t *testing.T) {
+		t.Setenv("STRIPE_DIAL_TIMEOUT_MS", "soon")
+		_, err := egress.LoadConfig("stripe")
+		assert.Error(t, err)
+	})
+
+	t.Run("should require both client certificate and key", func(t *testing.T) {
+		t.Setenv("STRIPE_CLIENT_CERT_FILE", "/etc/certs/client.pem")
+		_, err := egress.LoadConfig("stripe")
+		assert.Error(t, err)
+	})
+}
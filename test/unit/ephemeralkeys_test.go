@@ -0,0 +1,131 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"apis/payments/services/ephemeralkeys"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEphemeralKeys tests issuing and authenticating customer-scoped ephemeral keys
+func TestEphemeralKeys(t *testing.T) {
+	setup := func() (*ephemeralkeys.Service, *MockEphemeralKeyStore) {
+		store := NewMockEphemeralKeyStore()
+		config := &ephemeralkeys.Config{DefaultTTL: time.Hour, MaxTTL: 24 * time.Hour}
+		return ephemeralkeys.NewService(store, config), store
+	}
+
+	t.Run("should issue a key with every payment method scope by default", func(t *testing.T) {
+		service, store := setup()
+
+		key, err := service.Issue(context.Background(), &ephemeralkeys.IssueRequest{CustomerID: "cus_1"})
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(key.Secret, ephemeralkeys.SecretPrefix))
+		assert.True(t, key.Allows(ephemeralkeys.ScopePaymentMethodsRead))
+		assert.True(t, key.Allows(ephemeralkeys.ScopePaymentMethodsWrite))
+		assert.WithinDuration(t, time.Now().Add(time.Hour), key.ExpiresAt, time.Minute)
+
+		_, storedSecret := store.bySecret[key.Secret]
+		assert.False(t, storedSecret, "raw secrets must not be stored")
+	})
+
+	t.Run("should cap the TTL at the configured maximum", func(t *testing.T) {
+		service, _ := setup()
+
+		key, err := service.Issue(context.Background(), &ephemeralkeys.IssueRequest{CustomerID: "cus_1", TTLSeconds: 7 * 24 * 3600})
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(24*time.Hour), key.ExpiresAt, time.Minute)
+	})
+
+	t.Run("should reject unknown scopes", func(t *testing.T) {
+		service, _ := setup()
+
+		_, err := service.Issue(context.Background(), &ephemeralkeys.IssueRequest{CustomerID: "cus_1", Scopes: []string{"charges.write"}})
+		assert.ErrorIs(t, err, ephemeralkeys.ErrUnknownScope)
+	})
+
+	t.Run("should authenticate a key by its secret", func(t *testing.T) {
+		service, _ := setup()
+		issued, err := service.Issue(context.Background(), &ephemeralkeys.IssueRequest{
+			CustomerID: "cus_1",
+			Scopes:     []string{ephemeralkeys.ScopePaymentMethodsRead},
+		})
+		require.NoError(t, err)
+
+		key, err := service.Authenticate(context.Background(), issued.Secret)
+		require.NoError(t, err)
+		assert.Equal(t, "cus_1", key.CustomerID)
+		assert.False(t, key.Allows(ephemeralkeys.ScopePaymentMethodsWrite))
+	})
+
+	t.Run("should reject unknown, expired and revoked keys", func(t *testing.T) {
+		service, store := setup()
+
+		_, err := service.Authenticate(context.Background(), "sk_test_secret")
+		assert.ErrorIs(t, err, ephemeralkeys.ErrInvalidKey)
+		_, err = service.Authenticate(context.Background(), "ek_unknown")
+		assert.ErrorIs(t, err, ephemeralkeys.ErrInvalidKey)
+
+		expired, err := service.Issue(context.Background(), &ephemeralkeys.IssueRequest{CustomerID: "cus_1"})
+		require.NoError(t, err)
+		store.keys[ephemeralkeys.HashSecret(expired.Secret)].ExpiresAt = time.Now().Add(-time.Second)
+		_, err = service.Authenticate(context.Background(), expired.Secret)
+		assert.ErrorIs(t, err, ephemeralkeys.ErrInvalidKey)
+
+		revoked, err := service.Issue(context.Background(), &ephemeralkeys.IssueRequest{CustomerID: "cus_1"})
+		require.NoError(t, err)
+		require.NoError(t, service.Revoke(context.Background(), revoked.ID))
+		_, err = service.Authenticate(context.Background(), revoked.Secret)
+		assert.ErrorIs(t, err, ephemeralkeys.ErrInvalidKey)
+
+		assert.ErrorIs(t, service.Revoke(context.Background(), revoked.ID), ephemeralkeys.ErrInvalidKey)
+	})
+}
+
+// MockEphemeralKeyStore keeps ephemeral keys in memory by secret hash
+type MockEphemeralKeyStore struct {
+	keys     map[string]*ephemeralkeys.Key
+	bySecret map[string]bool
+}
+
+// NewMockEphemeralKeyStore creates an empty ephemeral key store
+func NewMockEphemeralKeyStore() *MockEphemeralKeyStore {
+	return &MockEphemeralKeyStore{
+		keys:     make(map[string]*ephemeralkeys.Key),
+		bySecret: make(map[string]bool),
+	}
+}
+
+func (m *MockEphemeralKeyStore) CreateEphemeralKey(ctx context.Context, key *ephemeralkeys.Key, secretHash string) (*ephemeralkeys.Key, error) {
+	stored := *key
+	m.keys[secretHash] = &stored
+	if key.Secret != "" {
+		m.bySecret[key.Secret] = true
+	}
+	copied := stored
+	return &copied, nil
+}
+
+func (m *MockEphemeralKeyStore) GetEphemeralKeyBySecretHash(ctx context.Context, secretHash string) (*ephemeralkeys.Key, error) {
+	key, ok := m.keys[secretHash]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return key, nil
+}
+
+func (m *MockEphemeralKeyStore) RevokeEphemeralKey(ctx context.Context, id string, revokedAt time.Time) (bool, error) {
+	for _, key := range m.keys {
+		if key.ID == id && key.RevokedAt == nil {
+			key.RevokedAt = &revokedAt
+			return true, nil
+		}
+	}
+	return false, nil
+}
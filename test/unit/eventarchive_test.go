@@ -0,0 +1,291 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"apis/payments/services/batching"
+	"apis/payments/services/eventarchive"
+	"apis/payments/services/events"
+	"apis/payments/services/merchantwebhooks"
+	"apis/payments/services/tenancy"
+	"apis/payments/services/tenantcredentials"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEventArchive tests archiving emitted events and replaying them to
+// topics and webhook endpoints
+func TestEventArchive(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	// newArchive creates an archive replaying two events at a time
+	newArchive := func(store eventarchive.Store, topics eventarchive.TopicPublisher, endpoints eventarchive.Endpoints) *eventarchive.Service {
+		service := eventarchive.NewService(store, topics, endpoints, &eventarchive.Config{Enabled: true, Retention: 24 * time.Hour, MaxReplay: 100})
+		service.UseBatchLimits(fixedBatches(2))
+		return service
+	}
+	// archive publishes events for tenants a minute apart
+	archive := func(t *testing.T, service *eventarchive.Service, tenants []string, types ...string) {
+		for i, eventType := range types {
+			ctx := tenancy.WithTenant(context.Background(), tenants[i%len(tenants)])
+			require.NoError(t, service.Publish(ctx, &events.Event{
+				SpecVersion: events.SpecVersion,
+				ID:          "evt_" + string(rune('a'+i)),
+				Type:        eventType,
+				Subject:     "ch_" + string(rune('a'+i%2)),
+				Time:        start.Add(time.Duration(i) * time.Minute),
+				Data:        json.RawMessage(`{}`),
+			}))
+		}
+	}
+	from := start.Add(-time.Hour)
+
+	t.Run("should archive each event once with its tenant", func(t *testing.T) {
+		store := NewMockEventArchiveStore()
+		service := newArchive(store, nil, nil)
+
+		archive(t, service, []string{"tenant_1"}, "payments.charge.succeeded")
+		archive(t, service, []string{"tenant_1"}, "payments.charge.succeeded")
+
+		records, err := service.List(context.Background(), eventarchive.Filter{})
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		assert.Equal(t, "tenant_1", records[0].TenantID)
+		assert.Equal(t, "evt_a", records[0].Event.ID)
+	})
+
+	t.Run("should replay matching events to a topic across batches", func(t *testing.T) {
+		store := NewMockEventArchiveStore()
+		topics := &MockEventTopicPublisher{}
+		service := newArchive(store, topics, nil)
+		archive(t, service, []string{"tenant_1", "tenant_2"},
+			"payments.charge.succeeded", "payments.refund.created", "payments.charge.refunded",
+			"payments.charge.failed", "payments.charge.succeeded", "payments.dispute.created")
+
+		to := start.Add(4 * time.Minute)
+		result, err := service.Replay(context.Background(), eventarchive.ReplayRequest{From: &from, To: &to, Type: "payments.charge.*", Topic: "payment-events.replay"})
+		require.NoError(t, err)
+		assert.Equal(t, 3, result.Matched)
+		assert.Equal(t, 3, result.Replayed)
+		assert.False(t, result.Truncated)
+
+		assert.Equal(t, []string{"evt_a", "evt_c", "evt_d"}, topics.ids("payment-events.replay"))
+	})
+
+	t.Run("should grow replay batches while publishing keeps up", func(t *testing.T) {
+		store := NewMockEventArchiveStore()
+		topics := &MockEventTopicPublisher{}
+		service := newArchive(store, topics, nil)
+		service.UseBatchLimits(&batching.Limits{MinSize: 1, MaxSize: 10, InitialSize: 1, Step: 1, TargetLatency: time.Minute, Backoff: 0.5})
+		archive(t, service, []string{"tenant_1"}, "payments.charge.succeeded", "payments.charge.succeeded", "payments.charge.succeeded", "payments.charge.succeeded")
+
+		result, err := service.Replay(context.Background(), eventarchive.ReplayRequest{From: &from, Topic: "replay"})
+		require.NoError(t, err)
+		assert.Equal(t, 4, result.Replayed)
+		assert.Equal(t, []string{"evt_a", "evt_b", "evt_c", "evt_d"}, topics.ids("replay"))
+		assert.Equal(t, 3, result.Batch.Size, "each full batch of 1, then 2, grows the next")
+		assert.Equal(t, int64(2), result.Batch.Increases)
+	})
+
+	t.Run("should replay events about one resource", func(t *testing.T) {
+		store := NewMockEventArchiveStore()
+		topics := &MockEventTopicPublisher{}
+		service := newArchive(store, topics, nil)
+		archive(t, service, []string{"tenant_1"}, "payments.charge.succeeded", "payments.charge.succeeded", "payments.charge.refunded")
+
+		result, err := service.Replay(context.Background(), eventarchive.ReplayRequest{From: &from, Subject: "ch_a", Topic: "replay"})
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Replayed)
+		assert.Equal(t, []string{"evt_a", "evt_c"}, topics.ids("replay"))
+	})
+
+	t.Run("should list events without publishing them on dry runs", func(t *testing.T) {
+		store := NewMockEventArchiveStore()
+		topics := &MockEventTopicPublisher{}
+		service := newArchive(store, topics, nil)
+		archive(t, service, []string{"tenant_1"}, "payments.charge.succeeded", "payments.refund.created", "payments.charge.failed")
+
+		result, err := service.Replay(context.Background(), eventarchive.ReplayRequest{From: &from, Topic: "replay", DryRun: true, Limit: 2})
+		require.NoError(t, err)
+		assert.True(t, result.DryRun)
+		assert.Equal(t, 2, result.Matched)
+		assert.Equal(t, 0, result.Replayed)
+		assert.True(t, result.Truncated)
+		require.Len(t, result.Events, 2)
+		assert.Equal(t, "payments.refund.created", result.Events[1].Type)
+		assert.Empty(t, topics.ids("replay"))
+	})
+
+	t.Run("should replay a tenant's subscribed events to its webhook endpoint", func(t *testing.T) {
+		cipher, err := tenantcredentials.NewCipher(make([]byte, 32))
+		require.NoError(t, err)
+		webhookStore := NewMockMerchantWebhookStore()
+		webhooks := merchantwebhooks.NewService(webhookStore, cipher, &merchantwebhooks.Config{Timeout: time.Second})
+		endpoint, err := webhooks.CreateEndpoint(context.Background(), "tenant_1", merchantwebhooks.CreateEndpointRequest{URL: "https://example.com/hooks", EventTypes: []string{"payments.charge.*"}})
+		require.NoError(t, err)
+
+		store := NewMockEventArchiveStore()
+		service := newArchive(store, nil, webhooks)
+		archive(t, service, []string{"tenant_1", "tenant_2"}, "payments.charge.succeeded", "payments.charge.failed", "payments.refund.created")
+
+		// A delivery already made is sent again
+		require.NoError(t, webhooks.Publish(tenancy.WithTenant(context.Background(), "tenant_1"), &events.Event{ID: "evt_a", Type: "payments.charge.succeeded"}))
+		for _, delivery := range webhookStore.deliveries {
+			delivery.Status = merchantwebhooks.StatusSucceeded
+			delivery.Attempts = 1
+		}
+
+		result, err := service.Replay(context.Background(), eventarchive.ReplayRequest{From: &from, TenantID: "tenant_1", EndpointID: endpoint.ID})
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Replayed)
+		assert.Equal(t, 1, result.Skipped)
+
+		deliveries, err := webhooks.ListDeliveries(context.Background(), "tenant_1", endpoint.ID, merchantwebhooks.StatusPending, 0)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		assert.Equal(t, "evt_a", deliveries[0].EventID)
+		assert.Equal(t, 0, deliveries[0].Attempts)
+
+		// Endpoints are looked up within the tenant
+		_, err = service.Replay(context.Background(), eventarchive.ReplayRequest{From: &from, TenantID: "tenant_2", EndpointID: endpoint.ID})
+		assert.Error(t, err)
+	})
+
+	t.Run("should reject invalid replays", func(t *testing.T) {
+		service := newArchive(NewMockEventArchiveStore(), nil, nil)
+
+		_, err := service.Replay(context.Background(), eventarchive.ReplayRequest{Topic: "replay"})
+		assert.ErrorIs(t, err, eventarchive.ErrInvalidReplay)
+
+		_, err = service.Replay(context.Background(), eventarchive.ReplayRequest{From: &from})
+		assert.ErrorIs(t, err, eventarchive.ErrInvalidReplay)
+
+		_, err = service.Replay(context.Background(), eventarchive.ReplayRequest{From: &from, Topic: "replay", EndpointID: "we_1", TenantID: "tenant_1"})
+		assert.ErrorIs(t, err, eventarchive.ErrInvalidReplay)
+
+		_, err = service.Replay(context.Background(), eventarchive.ReplayRequest{From: &from, EndpointID: "we_1"})
+		assert.ErrorIs(t, err, eventarchive.ErrInvalidReplay)
+
+		_, err = service.Replay(context.Background(), eventarchive.ReplayRequest{From: &from, Topic: "replay"})
+		assert.ErrorIs(t, err, eventarchive.ErrTopicsUnavailable)
+	})
+
+	t.Run("should purge events past the retention period", func(t *testing.T) {
+		store := NewMockEventArchiveStore()
+		service := newArchive(store, nil, nil)
+		archive(t, service, []string{"tenant_1"}, "payments.charge.succeeded", "payments.charge.failed")
+
+		purged, err := service.Purge(context.Background(), start.Add(24*time.Hour+30*time.Second))
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), purged)
+		assert.Len(t, store.records, 1)
+		assert.NotNil(t, service.Job())
+	})
+
+	t.Run("should convert type filters to escaped LIKE patterns", func(t *testing.T) {
+		assert.Equal(t, "", eventarchive.TypePattern(""))
+		assert.Equal(t, `payments.auto\_refund.created`, eventarchive.TypePattern("payments.auto_refund.created"))
+		assert.Equal(t, "payments.charge.%", eventarchive.TypePattern("payments.charge.*"))
+	})
+}
+
+// MockEventArchiveStore keeps archived events in memory
+type MockEventArchiveStore struct {
+	mu      sync.Mutex
+	records map[string]*eventarchive.Record
+}
+
+func NewMockEventArchiveStore() *MockEventArchiveStore {
+	return &MockEventArchiveStore{records: make(map[string]*eventarchive.Record)}
+}
+
+func (m *MockEventArchiveStore) ArchiveEvent(ctx context.Context, record *eventarchive.Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.records[record.Event.ID]; !ok {
+		record.ArchivedAt = time.Now()
+		m.records[record.Event.ID] = record
+	}
+	return nil
+}
+
+func (m *MockEventArchiveStore) ListArchivedEvents(ctx context.Context, filter eventarchive.Filter) ([]*eventarchive.Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var records []*eventarchive.Record
+	for _, record := range m.records {
+		event := record.Event
+		if event.Time.Before(filter.From) || !event.Time.Before(filter.To) {
+			continue
+		}
+		if filter.TenantID != "" && record.TenantID != filter.TenantID {
+			continue
+		}
+		if prefix, ok := strings.CutSuffix(filter.Type, "*"); filter.Type != "" && !(event.Type == filter.Type || ok && strings.HasPrefix(event.Type, prefix)) {
+			continue
+		}
+		if filter.Subject != "" && event.Subject != filter.Subject {
+			continue
+		}
+		if filter.After != nil && (event.Time.Before(filter.After.Time) || event.Time.Equal(filter.After.Time) && event.ID <= filter.After.ID) {
+			continue
+		}
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if !records[i].Event.Time.Equal(records[j].Event.Time) {
+			return records[i].Event.Time.Before(records[j].Event.Time)
+		}
+		return records[i].Event.ID < records[j].Event.ID
+	})
+	if len(records) > filter.Limit {
+		records = records[:filter.Limit]
+	}
+	return records, nil
+}
+
+func (m *MockEventArchiveStore) PurgeArchivedEvents(ctx context.Context, before time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var purged int64
+	for id, record := range m.records {
+		if record.Event.Time.Before(before) {
+			delete(m.records, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// MockEventTopicPublisher records the events published to each topic
+type MockEventTopicPublisher struct {
+	mu        sync.Mutex
+	published map[string][]*events.Event
+}
+
+func (m *MockEventTopicPublisher) PublishTo(ctx context.Context, topic string, event *events.Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.published == nil {
+		m.published = make(map[string][]*events.Event)
+	}
+	m.published[topic] = append(m.published[topic], event)
+	return nil
+}
+
+// ids returns the IDs of the events published to a topic, in order
+func (m *MockEventTopicPublisher) ids(topic string) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var ids []string
+	for _, event := range m.published[topic] {
+		ids = append(ids, event.ID)
+	}
+	return ids
+}
@@ -0,0 +1,47 @@
+package test
+
+import (
+	"testing"
+
+	"apis/payments/services/events"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEventSource tests building CloudEvents source URIs per deployment
+func TestEventSource(t *testing.T) {
+	t.Run("should default to the payments prefix", func(t *testing.T) {
+		t.Setenv("EVENT_SOURCE_PREFIX", "")
+		t.Setenv("DEPLOY_ENVIRONMENT", "")
+
+		source, err := events.LoadSource()
+		require.NoError(t, err)
+		assert.Equal(t, "/payments/charges", source.For("charges"))
+	})
+
+	t.Run("should use the configured prefix", func(t *testing.T) {
+		t.Setenv("EVENT_SOURCE_PREFIX", "//payments.prod.magebase/")
+
+		source, err := events.LoadSource()
+		require.NoError(t, err)
+		assert.Equal(t, "//payments.prod.magebase/charges", source.For("/charges"))
+		assert.Equal(t, "//payments.prod.magebase", source.For(""))
+	})
+
+	t.Run("should derive the prefix from environment and region", func(t *testing.T) {
+		t.Setenv("EVENT_SOURCE_PREFIX", "")
+		t.Setenv("DEPLOY_ENVIRONMENT", "prod")
+		t.Setenv("DEPLOY_REGION", "eu-west-1")
+		t.Setenv("EVENT_SOURCE_DOMAIN", "magebase")
+
+		source, err := events.LoadSource()
+		require.NoError(t, err)
+		assert.Equal(t, "//payments.prod.eu-west-1.magebase/refunds", source.For("refunds"))
+	})
+
+	t.Run("should reject an invalid prefix", func(t *testing.T) {
+		_, err := events.NewSource("://bad")
+		assert.Error(t, err)
+	})
+}
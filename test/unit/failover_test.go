@@ -0,0 +1,166 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+
+	"apis/payments/services"
+	"apis/payments/services/resilience"
+	"apis/payments/services/routing"
+	"apis/payments/services/tenancy"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// TestGatewayFailover tests failover rules and charging through provider chains
+func TestGatewayFailover(t *testing.T) {
+	ctx := context.Background()
+	rules, err := routing.ParseFailoverRules("eur:*:*=adyen>stripe, usd:us:-500000=stripe>square, *:*:1000-=stripe>paypal")
+	require.NoError(t, err)
+	router := routing.NewRouter(&MockRoutingStore{}, &routing.Config{DefaultProvider: "stripe", FailoverRules: rules})
+
+	setup := func(gateways ...*MockFailoverGateway) (*services.FailoverCharger, map[string]*MockFailoverGateway) {
+		byProvider := make(map[string]*MockFailoverGateway)
+		for _, gateway := range gateways {
+			byProvider[gateway.provider] = gateway
+		}
+		return services.NewFailoverCharger(MockFailoverGateways(byProvider), router), byProvider
+	}
+
+	t.Run("should parse rules and match the first one", func(t *testing.T) {
+		chain, ok := router.Chain("EUR", "de", 100)
+		assert.True(t, ok)
+		assert.Equal(t, []string{"adyen", "stripe"}, chain)
+
+		chain, _ = router.Chain("usd", "US", 500000)
+		assert.Equal(t, []string{"stripe", "square"}, chain)
+		chain, _ = router.Chain("usd", "US", 500001)
+		assert.Equal(t, []string{"stripe", "paypal"}, chain)
+
+		_, ok = router.Chain("gbp", "gb", 999)
+		assert.False(t, ok)
+
+		for _, raw := range []string{"eur=stripe", "eur:*:*=", "eur:*:abc=stripe"} {
+			_, err := routing.ParseFailoverRules(raw)
+			assert.Error(t, err, raw)
+		}
+	})
+
+	t.Run("should fall back when the primary is down", func(t *testing.T) {
+		charger, gateways := setup(
+			&MockFailoverGateway{provider: "adyen", err: resilience.ErrCircuitOpen},
+			&MockFailoverGateway{provider: "stripe"},
+		)
+
+		charge, attempts, err := charger.CreateCharge(ctx, tenancy.DefaultTenantID, "stripe", services.CreateChargeRequest{Amount: 2000, Currency: "eur"})
+		require.NoError(t, err)
+		assert.Equal(t, "stripe", charge.Provider)
+		require.Len(t, attempts, 2)
+		assert.Equal(t, services.FailoverFailed, attempts[0].Outcome)
+		assert.Equal(t, services.FailoverSucceeded, attempts[1].Outcome)
+		assert.Equal(t, 1, gateways["adyen"].calls)
+	})
+
+	t.Run("should fall back on infrastructure declines only", func(t *testing.T) {
+		charger, gateways := setup(
+			&MockFailoverGateway{provider: "adyen", err: &stripego.Error{HTTPStatusCode: http.StatusPaymentRequired, DeclineCode: stripego.DeclineCodeIssuerNotAvailable}},
+			&MockFailoverGateway{provider: "stripe"},
+		)
+		_, _, err := charger.CreateCharge(ctx, tenancy.DefaultTenantID, "stripe", services.CreateChargeRequest{Amount: 2000, Currency: "eur"})
+		require.NoError(t, err)
+
+		declined := &stripego.Error{HTTPStatusCode: http.StatusPaymentRequired, DeclineCode: stripego.DeclineCodeInsufficientFunds}
+		charger, gateways = setup(
+			&MockFailoverGateway{provider: "adyen", err: declined},
+			&MockFailoverGateway{provider: "stripe"},
+		)
+		_, attempts, err := charger.CreateCharge(ctx, tenancy.DefaultTenantID, "stripe", services.CreateChargeRequest{Amount: 2000, Currency: "eur"})
+		assert.ErrorIs(t, err, declined)
+		assert.Len(t, attempts, 1)
+		assert.Equal(t, 0, gateways["stripe"].calls, "card declines are not failed over")
+	})
+
+	t.Run("should skip providers whose capabilities rule out the charge", func(t *testing.T) {
+		charger, gateways := setup(
+			&MockFailoverGateway{provider: "adyen", currencies: []string{"usd"}},
+			&MockFailoverGateway{provider: "stripe"},
+		)
+
+		charge, attempts, err := charger.CreateCharge(ctx, tenancy.DefaultTenantID, "stripe", services.CreateChargeRequest{Amount: 2000, Currency: "eur"})
+		require.NoError(t, err)
+		assert.Equal(t, "stripe", charge.Provider)
+		assert.Equal(t, services.FailoverSkipped, attempts[0].Outcome)
+		assert.Equal(t, 0, gateways["adyen"].calls)
+	})
+
+	t.Run("should report when every provider fails or none can take the charge", func(t *testing.T) {
+		unavailable := &stripego.Error{HTTPStatusCode: http.StatusServiceUnavailable}
+		charger, _ := setup(
+			&MockFailoverGateway{provider: "adyen", err: unavailable},
+			&MockFailoverGateway{provider: "stripe", err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}},
+		)
+		_, attempts, err := charger.CreateCharge(ctx, tenancy.DefaultTenantID, "stripe", services.CreateChargeRequest{Amount: 2000, Currency: "eur"})
+		assert.Error(t, err)
+		assert.Len(t, attempts, 2)
+
+		charger, _ = setup()
+		_, _, err = charger.CreateCharge(ctx, tenancy.DefaultTenantID, "stripe", services.CreateChargeRequest{Amount: 2000, Currency: "eur"})
+		assert.ErrorIs(t, err, services.ErrNoFailoverProvider)
+	})
+
+	t.Run("should use the default provider when no rule matches", func(t *testing.T) {
+		charger, gateways := setup(&MockFailoverGateway{provider: "square"})
+
+		charge, _, err := charger.CreateCharge(ctx, "acme", "square", services.CreateChargeRequest{Amount: 500, Currency: "gbp"})
+		require.NoError(t, err)
+		assert.Equal(t, "square", charge.Provider)
+		assert.Equal(t, 1, gateways["square"].calls)
+	})
+
+	t.Run("should not fail over ambiguous errors", func(t *testing.T) {
+		assert.False(t, services.IsFailoverError(&stripego.Error{HTTPStatusCode: http.StatusInternalServerError}))
+		assert.False(t, services.IsFailoverError(context.DeadlineExceeded))
+		assert.True(t, services.IsFailoverError(&stripego.Error{HTTPStatusCode: http.StatusTooManyRequests}))
+	})
+}
+
+// MockFailoverGateways resolves gateways by provider
+type MockFailoverGateways map[string]*MockFailoverGateway
+
+func (m MockFailoverGateways) Gateway(ctx context.Context, tenantID, provider string) (services.PaymentGateway, error) {
+	gateway, ok := m[provider]
+	if !ok {
+		return nil, tenancy.ErrNoCredentials
+	}
+	return gateway, nil
+}
+
+// MockFailoverGateway charges successfully unless given an error
+type MockFailoverGateway struct {
+	services.PaymentGateway
+	provider   string
+	currencies []string
+	err        error
+	calls      int
+}
+
+func (m *MockFailoverGateway) GetProvider() string {
+	return m.provider
+}
+
+func (m *MockFailoverGateway) GetCapabilities() services.GatewayCapabilities {
+	return services.GatewayCapabilities{SupportsCharges: true, SupportedCurrencies: m.currencies}
+}
+
+func (m *MockFailoverGateway) CreateCharge(ctx context.Context, req services.CreateChargeRequest) (*services.Charge, error) {
+	m.calls++
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &services.Charge{ID: "ch_" + m.provider, Amount: req.Amount, Currency: req.Currency, Provider: m.provider}, nil
+}
@@ -0,0 +1,122 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"apis/payments/services/events"
+	"apis/payments/services/fingerprints"
+	"apis/payments/services/fraud"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCardFingerprints tests flagging and blocking cards shared across
+// customers or charged back, and screening charges with the same checks
+func TestCardFingerprints(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(enabled bool) (*fingerprints.Service, *MockFingerprintStore, *MockEventPublisher) {
+		store := &MockFingerprintStore{}
+		publisher := &MockEventPublisher{}
+		source, err := events.NewSource("/payments")
+		require.NoError(t, err)
+		service := fingerprints.NewService(store, events.NewEmitter(source, publisher), &fingerprints.Config{
+			Enabled:              enabled,
+			SharedCustomersFlag:  3,
+			SharedCustomersBlock: 10,
+			ChargebacksFlag:      1,
+			ChargebacksBlock:     2,
+			ChargebackLookback:   365 * 24 * time.Hour,
+		})
+		return service, store, publisher
+	}
+
+	t.Run("should allow cards without a history", func(t *testing.T) {
+		service, store, publisher := setup(true)
+		store.customers = 2
+
+		assessment, err := service.Check(ctx, "tenant_1", "fp_1", "cus_1")
+		require.NoError(t, err)
+		assert.Equal(t, fingerprints.DecisionAllow, assessment.Decision)
+		assert.Equal(t, int64(2), assessment.SharedCustomers)
+		assert.Equal(t, "cus_1", store.excluded, "the customer's own cards aren't counted")
+		assert.Empty(t, publisher.events)
+	})
+
+	t.Run("should flag shared cards and block charged back ones", func(t *testing.T) {
+		service, store, publisher := setup(true)
+		store.customers = 3
+
+		assessment, err := service.Check(ctx, "tenant_1", "fp_1", "cus_1")
+		require.NoError(t, err)
+		assert.Equal(t, fingerprints.DecisionFlag, assessment.Decision)
+		require.Len(t, publisher.events, 1)
+		assert.Equal(t, fingerprints.EventCardFlagged, publisher.events[0].Type)
+
+		store.chargebacks = 2
+		assessment, err = service.Check(ctx, "tenant_1", "fp_1", "cus_1")
+		assert.ErrorIs(t, err, fingerprints.ErrBlocked)
+		assert.Equal(t, fingerprints.DecisionBlock, assessment.Decision)
+		assert.Len(t, assessment.Reasons, 2)
+		assert.WithinDuration(t, time.Now().Add(-365*24*time.Hour), store.since, time.Minute)
+		assert.Len(t, publisher.events, 2)
+	})
+
+	t.Run("should not check cards while disabled", func(t *testing.T) {
+		service, store, publisher := setup(false)
+		store.customers = 50
+
+		assessment, err := service.Check(ctx, "tenant_1", "fp_1", "cus_1")
+		require.NoError(t, err)
+		assert.Equal(t, fingerprints.DecisionAllow, assessment.Decision)
+		assert.Empty(t, publisher.events)
+
+		assessment, err = service.Assess(ctx, "tenant_1", "fp_1", "cus_1")
+		require.NoError(t, err)
+		assert.Equal(t, fingerprints.DecisionBlock, assessment.Decision, "cards can still be looked up")
+	})
+
+	t.Run("should review flagged cards and reject blocked ones in fraud screening", func(t *testing.T) {
+		service, store, _ := setup(true)
+		rule := fingerprints.NewFraudRule(service)
+		screening := &fraud.Screening{TenantID: "tenant_1", CustomerID: "cus_1", CardFingerprint: "fp_1"}
+
+		finding, err := rule.Evaluate(ctx, screening)
+		require.NoError(t, err)
+		assert.Nil(t, finding)
+
+		store.chargebacks = 1
+		finding, err = rule.Evaluate(ctx, screening)
+		require.NoError(t, err)
+		require.NotNil(t, finding)
+		assert.Equal(t, fraud.DecisionReview, finding.Decision)
+
+		store.customers = 10
+		finding, err = rule.Evaluate(ctx, screening)
+		require.NoError(t, err)
+		assert.Equal(t, fraud.DecisionReject, finding.Decision)
+		assert.Contains(t, finding.Reason, "card saved by 10 other customers")
+	})
+}
+
+// MockFingerprintStore reports fixed fingerprint counts, remembering what
+// they were asked for
+type MockFingerprintStore struct {
+	customers   int64
+	chargebacks int64
+	excluded    string
+	since       time.Time
+}
+
+func (m *MockFingerprintStore) CountFingerprintCustomers(ctx context.Context, tenantID, fingerprint, excludeCustomerID string) (int64, error) {
+	m.excluded = excludeCustomerID
+	return m.customers, nil
+}
+
+func (m *MockFingerprintStore) CountFingerprintChargebacks(ctx context.Context, tenantID, fingerprint string, since time.Time) (int64, error) {
+	m.since = since
+	return m.chargebacks, nil
+}
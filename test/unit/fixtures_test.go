@@ -0,0 +1,43 @@
+package test
+
+import (
+	"testing"
+
+	"apis/payments/services/stripe"
+	"apis/payments/test/fixtures"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFixtures tests fixture builders and canonical golden output
+func TestFixtures(t *testing.T) {
+	t.Run("should apply overrides to a fresh fixture", func(t *testing.T) {
+		failed := fixtures.Charge(func(c *stripe.Charge) { c.Status = "failed" })
+		captured := fixtures.Charge()
+
+		assert.Equal(t, "failed", failed.Status)
+		assert.Equal(t, "succeeded", captured.Status)
+		assert.Equal(t, captured.ID, failed.ID)
+	})
+
+	t.Run("should sort keys at every depth", func(t *testing.T) {
+		out, err := fixtures.Canonical([]byte(`{"b": 1, "a": {"z": true, "y": [{"d": 1, "c": 2}]}}`))
+		require.NoError(t, err)
+
+		assert.Equal(t, "{\n  \"a\": {\n    \"y\": [\n      {\n        \"c\": 2,\n        \"d\": 1\n      }\n    ],\n    \"z\": true\n  },\n  \"b\": 1\n}\n", string(out))
+	})
+
+	t.Run("should scrub named fields and keep numbers exact", func(t *testing.T) {
+		out, err := fixtures.Canonical(map[string]any{
+			"amount":  int64(9007199254740993),
+			"updated": 1704067200,
+			"items":   []any{map[string]any{"updated": 1}},
+		}, "updated")
+		require.NoError(t, err)
+
+		assert.Contains(t, string(out), `"amount": 9007199254740993`)
+		assert.NotContains(t, string(out), "1704067200")
+		assert.Contains(t, string(out), `"updated": "`+fixtures.Scrubbed+`"`)
+	})
+}
@@ -0,0 +1,380 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"apis/payments/services/events"
+	"apis/payments/services/fraud"
+	"apis/payments/services/stripe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFraudScreening tests screening charges with lists and rules, holding
+// suspicious charges for review
+func TestFraudScreening(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func() (*fraud.Service, *MockFraudStore, *MockFraudCharger, *MockEventPublisher) {
+		store := &MockFraudStore{reviews: make(map[string]*fraud.Review)}
+		charger := &MockFraudCharger{riskScore: 20}
+		publisher := &MockEventPublisher{}
+		source, err := events.NewSource("/payments")
+		require.NoError(t, err)
+		service := fraud.NewService(store, charger, events.NewEmitter(source, publisher), &fraud.Config{
+			Enabled:          true,
+			VelocityWindow:   time.Hour,
+			VelocityReview:   2,
+			VelocityReject:   3,
+			AmountBaseline:   30 * 24 * time.Hour,
+			AmountMinHistory: 2,
+			AmountMultiplier: 5,
+			RadarReviewScore: 65,
+			RadarRejectScore: 85,
+			RadarLookback:    30 * 24 * time.Hour,
+		})
+		return service, store, charger, publisher
+	}
+
+	request := func(amount int64) *stripe.ChargeRequest {
+		return &stripe.ChargeRequest{Amount: amount, Currency: "USD", CustomerID: "cus_1", PaymentMethod: "pm_1"}
+	}
+
+	screening := func(ip string) *fraud.Screening {
+		return &fraud.Screening{TenantID: "tenant_1", CardFingerprint: "fp_1", IPAddress: ip}
+	}
+
+	t.Run("should accept clean charges and ingest their Radar scores", func(t *testing.T) {
+		service, store, charger, _ := setup()
+
+		charge, review, err := service.CreateCharge(ctx, screening("10.0.0.1"), request(1000))
+		require.NoError(t, err)
+		assert.Nil(t, review)
+		assert.Equal(t, "ch_1", charge.ID)
+		assert.Len(t, charger.requests, 1)
+		require.Len(t, store.screenings, 1)
+		assert.Equal(t, fraud.DecisionAccept, store.screenings[0].Decision)
+		assert.Equal(t, "cus_1", store.screenings[0].CustomerID)
+		assert.Equal(t, "usd", store.screenings[0].Currency)
+		require.Len(t, store.scores, 1)
+		assert.Equal(t, "fp_1", store.scores[0].CardFingerprint)
+	})
+
+	t.Run("should hold charges over the velocity limit and reject beyond it", func(t *testing.T) {
+		service, store, charger, publisher := setup()
+
+		for i := 0; i < 2; i++ {
+			_, review, err := service.CreateCharge(ctx, screening(fmt.Sprintf("10.0.0.%d", i)), request(1000))
+			require.NoError(t, err)
+			assert.Nil(t, review)
+		}
+
+		charge, review, err := service.CreateCharge(ctx, screening("10.0.0.9"), request(1000))
+		require.NoError(t, err)
+		assert.Nil(t, charge)
+		require.NotNil(t, review)
+		assert.Equal(t, fraud.ReviewPending, review.Status)
+		assert.Equal(t, "velocity", review.Findings[0].Rule)
+		assert.Len(t, charger.requests, 2, "held charges are not created")
+		require.Len(t, publisher.events, 1)
+		assert.Equal(t, fraud.EventChargeFlagged, publisher.events[0].Type)
+		assert.Equal(t, review.ID, publisher.events[0].Subject)
+
+		_, _, err = service.CreateCharge(ctx, screening("10.0.0.9"), request(1000))
+		assert.ErrorIs(t, err, fraud.ErrRejected)
+		assert.Len(t, store.screenings, 4, "rejected charges still count towards velocity")
+	})
+
+	t.Run("should review charges far above the customer's average", func(t *testing.T) {
+		service, store, _, _ := setup()
+		store.history = fraud.AmountHistory{Count: 2, Average: 1000}
+
+		assessment, err := service.Assess(ctx, &fraud.Screening{TenantID: "tenant_1", CustomerID: "cus_1", Amount: 5000, Currency: "usd"})
+		require.NoError(t, err)
+		assert.Equal(t, fraud.DecisionAccept, assessment.Decision)
+
+		assessment, err = service.Assess(ctx, &fraud.Screening{TenantID: "tenant_1", CustomerID: "cus_1", Amount: 5001, Currency: "usd"})
+		require.NoError(t, err)
+		assert.Equal(t, fraud.DecisionReview, assessment.Decision)
+		assert.Equal(t, "amount_anomaly", assessment.Findings[0].Rule)
+
+		store.history = fraud.AmountHistory{Count: 1, Average: 10}
+		assessment, err = service.Assess(ctx, &fraud.Screening{TenantID: "tenant_1", CustomerID: "cus_1", Amount: 5001, Currency: "usd"})
+		require.NoError(t, err)
+		assert.Equal(t, fraud.DecisionAccept, assessment.Decision, "customers with little history are not judged")
+	})
+
+	t.Run("should screen against recent Radar scores", func(t *testing.T) {
+		service, store, _, _ := setup()
+
+		store.scores = []*fraud.RadarScore{{ChargeID: "ch_old", CardFingerprint: "fp_1", RiskScore: 70, RiskLevel: "elevated"}}
+		assessment, err := service.Assess(ctx, screening(""))
+		require.NoError(t, err)
+		assert.Equal(t, fraud.DecisionReview, assessment.Decision)
+
+		store.scores[0].RiskScore = 90
+		assessment, err = service.Assess(ctx, screening(""))
+		require.NoError(t, err)
+		assert.Equal(t, fraud.DecisionReject, assessment.Decision)
+	})
+
+	t.Run("should let allow lists override deny lists and rules", func(t *testing.T) {
+		service, store, _, _ := setup()
+		store.scores = []*fraud.RadarScore{{ChargeID: "ch_old", CardFingerprint: "fp_1", RiskScore: 90}}
+
+		deny, err := service.AddListEntry(ctx, &fraud.ListEntry{List: fraud.ListDeny, Type: fraud.EntryIPAddress, Value: " 10.0.0.1 "})
+		require.NoError(t, err)
+		assert.Equal(t, "10.0.0.1", deny.Value)
+
+		_, _, err = service.CreateCharge(ctx, screening("10.0.0.2"), request(1000))
+		assert.ErrorIs(t, err, fraud.ErrRejected, "Radar score")
+
+		_, err = service.AddListEntry(ctx, &fraud.ListEntry{List: fraud.ListAllow, Type: fraud.EntryCustomer, Value: "cus_1"})
+		require.NoError(t, err)
+		charge, _, err := service.CreateCharge(ctx, screening("10.0.0.1"), request(1000))
+		require.NoError(t, err)
+		assert.NotNil(t, charge)
+
+		for _, entry := range []*fraud.ListEntry{
+			{List: "grey", Type: fraud.EntryCustomer, Value: "cus_1"},
+			{List: fraud.ListDeny, Type: "email", Value: "a@example.com"},
+			{List: fraud.ListDeny, Type: fraud.EntryCustomer, Value: " "},
+		} {
+			_, err := service.AddListEntry(ctx, entry)
+			assert.ErrorIs(t, err, fraud.ErrInvalidListEntry)
+		}
+
+		require.NoError(t, service.RemoveListEntry(ctx, deny.ID))
+		assert.ErrorIs(t, service.RemoveListEntry(ctx, deny.ID), fraud.ErrListEntryNotFound)
+	})
+
+	t.Run("should skip failing rules and run pluggable ones", func(t *testing.T) {
+		service, _, _, _ := setup()
+		service.Use(&MockFraudRule{err: errors.New("rule backend down")})
+		service.Use(&MockFraudRule{finding: &fraud.Finding{Rule: "custom", Decision: fraud.DecisionReview, Reason: "looks odd"}})
+
+		assessment, err := service.Assess(ctx, screening("10.0.0.1"))
+		require.NoError(t, err)
+		assert.Equal(t, fraud.DecisionReview, assessment.Decision)
+		assert.Equal(t, "custom", assessment.Findings[0].Rule)
+	})
+
+	t.Run("should create approved charges once and record failures", func(t *testing.T) {
+		service, store, charger, _ := setup()
+		service.Use(&MockFraudRule{finding: &fraud.Finding{Rule: "custom", Decision: fraud.DecisionReview}})
+
+		_, review, err := service.CreateCharge(ctx, screening("10.0.0.1"), request(1000))
+		require.NoError(t, err)
+
+		_, err = service.ApproveReview(ctx, review.ID, "")
+		assert.Error(t, err, "a reviewer is required")
+
+		approved, err := service.ApproveReview(ctx, review.ID, "op_1")
+		require.NoError(t, err)
+		assert.Equal(t, fraud.ReviewApproved, approved.Status)
+		assert.Equal(t, "ch_1", approved.ChargeID)
+		assert.Equal(t, "op_1", approved.DecidedBy)
+
+		_, err = service.ApproveReview(ctx, review.ID, "op_2")
+		assert.ErrorIs(t, err, fraud.ErrReviewNotPending)
+		_, err = service.RejectReview(ctx, review.ID, "op_2")
+		assert.ErrorIs(t, err, fraud.ErrReviewNotPending)
+		assert.Len(t, charger.requests, 1)
+
+		_, review, err = service.CreateCharge(ctx, screening("10.0.0.2"), request(1000))
+		require.NoError(t, err)
+		charger.err = errors.New("card declined")
+		_, err = service.ApproveReview(ctx, review.ID, "op_1")
+		assert.Error(t, err)
+		assert.Equal(t, fraud.ReviewFailed, store.reviews[review.ID].Status)
+		assert.Equal(t, "card declined", store.reviews[review.ID].FailureReason)
+
+		_, err = service.GetReview(ctx, "frv_missing")
+		assert.ErrorIs(t, err, fraud.ErrReviewNotFound)
+	})
+
+	t.Run("should charge directly when screening is disabled", func(t *testing.T) {
+		store := &MockFraudStore{reviews: make(map[string]*fraud.Review)}
+		charger := &MockFraudCharger{}
+		source, err := events.NewSource("/payments")
+		require.NoError(t, err)
+		service := fraud.NewService(store, charger, events.NewEmitter(source, &MockEventPublisher{}), &fraud.Config{})
+
+		charge, review, err := service.CreateCharge(ctx, screening("10.0.0.1"), request(1000))
+		require.NoError(t, err)
+		assert.Nil(t, review)
+		assert.NotNil(t, charge)
+		assert.Empty(t, store.screenings)
+	})
+}
+
+// MockFraudStore keeps fraud screenings, lists, scores and reviews in memory
+type MockFraudStore struct {
+	screenings []*fraud.Screening
+	entries    []*fraud.ListEntry
+	scores     []*fraud.RadarScore
+	reviews    map[string]*fraud.Review
+	history    fraud.AmountHistory
+}
+
+func (m *MockFraudStore) RecordFraudScreening(ctx context.Context, screening *fraud.Screening) error {
+	stored := *screening
+	m.screenings = append(m.screenings, &stored)
+	return nil
+}
+
+func (m *MockFraudStore) CountRecentFraudScreenings(ctx context.Context, screening *fraud.Screening, since time.Time) (fraud.Counts, error) {
+	var counts fraud.Counts
+	for _, s := range m.screenings {
+		if screening.CustomerID != "" && s.CustomerID == screening.CustomerID {
+			counts.Customer++
+		}
+		if screening.CardFingerprint != "" && s.CardFingerprint == screening.CardFingerprint {
+			counts.Card++
+		}
+		if screening.IPAddress != "" && s.IPAddress == screening.IPAddress {
+			counts.IP++
+		}
+	}
+	return counts, nil
+}
+
+func (m *MockFraudStore) GetCustomerScreenedAmounts(ctx context.Context, tenantID, customerID, currency string, since time.Time) (fraud.AmountHistory, error) {
+	return m.history, nil
+}
+
+func (m *MockFraudStore) CreateFraudListEntry(ctx context.Context, entry *fraud.ListEntry) (*fraud.ListEntry, error) {
+	m.entries = append(m.entries, entry)
+	return entry, nil
+}
+
+func (m *MockFraudStore) ListFraudListEntries(ctx context.Context, list string) ([]*fraud.ListEntry, error) {
+	var entries []*fraud.ListEntry
+	for _, entry := range m.entries {
+		if list == "" || entry.List == list {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func (m *MockFraudStore) MatchFraudListEntries(ctx context.Context, screening *fraud.Screening) ([]*fraud.ListEntry, error) {
+	values := map[string]string{
+		fraud.EntryCustomer:        screening.CustomerID,
+		fraud.EntryCardFingerprint: screening.CardFingerprint,
+		fraud.EntryIPAddress:       screening.IPAddress,
+	}
+	var entries []*fraud.ListEntry
+	for _, entry := range m.entries {
+		if values[entry.Type] == entry.Value {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func (m *MockFraudStore) DeleteFraudListEntry(ctx context.Context, id string) (bool, error) {
+	for i, entry := range m.entries {
+		if entry.ID == id {
+			m.entries = append(m.entries[:i], m.entries[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *MockFraudStore) RecordRadarScore(ctx context.Context, score *fraud.RadarScore) error {
+	m.scores = append(m.scores, score)
+	return nil
+}
+
+func (m *MockFraudStore) GetLatestRadarScore(ctx context.Context, customerID, cardFingerprint string, since time.Time) (*fraud.RadarScore, error) {
+	for i := len(m.scores) - 1; i >= 0; i-- {
+		score := m.scores[i]
+		if (customerID != "" && score.CustomerID == customerID) || (cardFingerprint != "" && score.CardFingerprint == cardFingerprint) {
+			return score, nil
+		}
+	}
+	return nil, fmt.Errorf("failed to get latest Radar score: %w", sql.ErrNoRows)
+}
+
+func (m *MockFraudStore) CreateFraudReview(ctx context.Context, review *fraud.Review) (*fraud.Review, error) {
+	stored := *review
+	m.reviews[review.ID] = &stored
+	return &stored, nil
+}
+
+func (m *MockFraudStore) GetFraudReview(ctx context.Context, id string) (*fraud.Review, error) {
+	review, ok := m.reviews[id]
+	if !ok {
+		return nil, fmt.Errorf("failed to get fraud review: %w", sql.ErrNoRows)
+	}
+	return review, nil
+}
+
+func (m *MockFraudStore) ListFraudReviews(ctx context.Context, tenantID, status string, limit int) ([]*fraud.Review, error) {
+	var reviews []*fraud.Review
+	for _, review := range m.reviews {
+		if review.TenantID == tenantID && (status == "" || review.Status == status) {
+			reviews = append(reviews, review)
+		}
+	}
+	return reviews, nil
+}
+
+func (m *MockFraudStore) DecideFraudReview(ctx context.Context, id, status, decidedBy string) (*fraud.Review, error) {
+	review, ok := m.reviews[id]
+	if !ok || review.Status != fraud.ReviewPending {
+		return nil, fmt.Errorf("failed to decide fraud review: %w", sql.ErrNoRows)
+	}
+	review.Status, review.DecidedBy = status, decidedBy
+	return review, nil
+}
+
+func (m *MockFraudStore) RecordFraudReviewCharge(ctx context.Context, id, status, chargeID, failureReason string) (*fraud.Review, error) {
+	review := m.reviews[id]
+	review.Status, review.ChargeID, review.FailureReason = status, chargeID, failureReason
+	return review, nil
+}
+
+// MockFraudCharger records the charges created after screening
+type MockFraudCharger struct {
+	requests  []*stripe.ChargeRequest
+	riskScore int64
+	err       error
+}
+
+func (m *MockFraudCharger) CreateCharge(ctx context.Context, tenantID string, request *stripe.ChargeRequest) (*stripe.Charge, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.requests = append(m.requests, request)
+	return &stripe.Charge{
+		ID:         fmt.Sprintf("ch_%d", len(m.requests)),
+		Amount:     request.Amount,
+		Currency:   request.Currency,
+		CustomerID: request.CustomerID,
+		RiskScore:  m.riskScore,
+		RiskLevel:  "normal",
+	}, nil
+}
+
+// MockFraudRule returns a fixed finding or error
+type MockFraudRule struct {
+	finding *fraud.Finding
+	err     error
+}
+
+func (m *MockFraudRule) Name() string {
+	return "mock"
+}
+
+func (m *MockFraudRule) Evaluate(ctx context.Context, screening *fraud.Screening) (*fraud.Finding, error) {
+	return m.finding, m.err
+}
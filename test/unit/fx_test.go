@@ -0,0 +1,146 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"apis/payments/services/fx"
+	"apis/payments/services/money"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCurrencyConversion tests minor-unit aware conversion between currencies
+// at cached provider rates
+func TestCurrencyConversion(t *testing.T) {
+	ctx := context.Background()
+
+	rate := func(s string) *big.Rat {
+		r, ok := new(big.Rat).SetString(s)
+		require.True(t, ok, s)
+		return r
+	}
+
+	ecbRates := func() *fx.Rates {
+		return &fx.Rates{
+			Provider: fx.ProviderECB,
+			Base:     "eur",
+			Quotes: map[string]*big.Rat{
+				"usd": rate("1.0800"),
+				"jpy": rate("162.00"),
+				"gbp": rate("0.8500"),
+			},
+			AsOf: time.Date(2026, time.October, 15, 0, 0, 0, 0, time.UTC),
+		}
+	}
+
+	t.Run("should account for minor units when converting", func(t *testing.T) {
+		// 1000 JPY is 1000 minor units; 1000 * 0.0067 = 6.70 USD
+		converted := money.New(1000, "JPY").Convert("usd", rate("0.0067"))
+		assert.Equal(t, money.New(670, "usd"), converted)
+
+		// 10.00 USD at 150 is 1500 JPY, not 150000
+		converted = money.New(1000, "usd").Convert("jpy", rate("150"))
+		assert.Equal(t, int64(1500), converted.Amount)
+
+		// 1.000 KWD at 3.25 is 3.25 USD
+		converted = money.New(1000, "kwd").Convert("usd", rate("3.25"))
+		assert.Equal(t, int64(325), converted.Amount)
+	})
+
+	t.Run("should round half away from zero", func(t *testing.T) {
+		assert.Equal(t, int64(2), money.New(1, "usd").Convert("eur", rate("1.5")).Amount)
+		assert.Equal(t, int64(1), money.New(1, "usd").Convert("eur", rate("1.49")).Amount)
+		assert.Equal(t, int64(-2), money.New(-1, "usd").Convert("eur", rate("1.5")).Amount)
+	})
+
+	t.Run("should validate amounts against per-currency minimums", func(t *testing.T) {
+		assert.NoError(t, money.ValidateAmount(50, "usd"))
+		assert.ErrorIs(t, money.ValidateAmount(49, "usd"), money.ErrBelowMinimum)
+
+		// ¥50 is 50 minor units, not 5000
+		assert.NoError(t, money.ValidateAmount(50, "jpy"))
+		assert.ErrorIs(t, money.ValidateAmount(10, "jpy"), money.ErrBelowMinimum)
+
+		assert.NoError(t, money.ValidateAmount(1, "kwd"))
+		assert.ErrorIs(t, money.ValidateAmount(100, "us"), money.ErrInvalidCurrency)
+		assert.Error(t, money.ValidateAmount(0, "usd"))
+	})
+
+	t.Run("should cross rates through the base currency", func(t *testing.T) {
+		service := fx.NewService(&MockRateProvider{rates: ecbRates()}, &fx.Config{CacheTTL: time.Hour})
+
+		conversion, err := service.Convert(ctx, money.New(1000, "USD"), "JPY")
+		require.NoError(t, err)
+
+		// 162 / 1.08 = 150 JPY per USD
+		assert.Equal(t, money.New(1500, "jpy"), conversion.To)
+		assert.Equal(t, "150.00000000", conversion.Rate)
+		assert.Equal(t, "¥1,500", conversion.ToDisplay.Formatted)
+		assert.Equal(t, fx.ProviderECB, conversion.Provider)
+	})
+
+	t.Run("should reject currencies the provider does not quote", func(t *testing.T) {
+		service := fx.NewService(&MockRateProvider{rates: ecbRates()}, &fx.Config{CacheTTL: time.Hour})
+
+		_, err := service.Convert(ctx, money.New(1000, "usd"), "xyz")
+		assert.ErrorIs(t, err, fx.ErrUnsupportedCurrency)
+
+		_, err = service.Convert(ctx, money.New(1000, "usd"), "yen")
+		assert.ErrorIs(t, err, fx.ErrUnsupportedCurrency)
+
+		_, err = service.Convert(ctx, money.New(1000, "usd"), "jp")
+		assert.ErrorIs(t, err, money.ErrInvalidCurrency)
+	})
+
+	t.Run("should cache rates until they expire", func(t *testing.T) {
+		provider := &MockRateProvider{rates: ecbRates()}
+		service := fx.NewService(provider, &fx.Config{CacheTTL: time.Hour})
+
+		for i := 0; i < 3; i++ {
+			_, err := service.Convert(ctx, money.New(1000, "usd"), "gbp")
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 1, provider.calls)
+	})
+
+	t.Run("should fall back to expired rates when the provider fails", func(t *testing.T) {
+		provider := &MockRateProvider{rates: ecbRates()}
+		service := fx.NewService(provider, &fx.Config{CacheTTL: time.Nanosecond})
+
+		_, err := service.Convert(ctx, money.New(1000, "usd"), "gbp")
+		require.NoError(t, err)
+
+		provider.err = errors.New("connection refused")
+		conversion, err := service.Convert(ctx, money.New(1000, "usd"), "gbp")
+		require.NoError(t, err)
+		assert.Equal(t, int64(787), conversion.To.Amount)
+		assert.Equal(t, 2, provider.calls)
+	})
+
+	t.Run("should fail when no rates have been fetched", func(t *testing.T) {
+		service := fx.NewService(&MockRateProvider{err: errors.New("connection refused")}, &fx.Config{CacheTTL: time.Hour})
+
+		_, err := service.Convert(ctx, money.New(1000, "usd"), "gbp")
+		assert.ErrorIs(t, err, fx.ErrRatesUnavailable)
+	})
+}
+
+// MockRateProvider serves fixed exchange rates
+type MockRateProvider struct {
+	rates *fx.Rates
+	err   error
+	calls int
+}
+
+func (m *MockRateProvider) LatestRates(ctx context.Context) (*fx.Rates, error) {
+	m.calls++
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.rates, nil
+}
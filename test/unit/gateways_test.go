@@ -0,0 +1,54 @@
+package test
+
+import (
+	"testing"
+
+	"apis/payments/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGateways tests that every provider is served through the one gateway interface hierarchy
+func TestGateways(t *testing.T) {
+	t.Run("should implement the gateway interfaces for every provider", func(t *testing.T) {
+		gateways := []interface{}{
+			&services.StripeGateway{},
+			&services.PaddleGateway{},
+			&services.SquareGateway{},
+			&services.PayPalGateway{},
+		}
+		for _, gateway := range gateways {
+			assert.Implements(t, (*services.PaymentGateway)(nil), gateway)
+		}
+
+		assert.Implements(t, (*services.DisputeManager)(nil), &services.StripeGateway{})
+		assert.Implements(t, (*services.InvoiceGateway)(nil), &services.StripeGateway{})
+		assert.True(t, (&services.StripeGateway{}).GetCapabilities().SupportsInvoices)
+		assert.Implements(t, (*services.ConnectGateway)(nil), &services.StripeGateway{})
+		assert.True(t, (&services.StripeGateway{}).GetCapabilities().SupportsConnect)
+		assert.Implements(t, (*services.PaymentLinkGateway)(nil), &services.StripeGateway{})
+		assert.True(t, (&services.StripeGateway{}).GetCapabilities().SupportsPaymentLinks)
+	})
+
+	t.Run("should register every provider with the factory", func(t *testing.T) {
+		providers := services.GetFactory().GetSupportedProviders()
+		assert.ElementsMatch(t, []string{"stripe", "paddle", "square", "paypal"}, providers)
+	})
+
+	t.Run("should reject stripe configs without a secret key", func(t *testing.T) {
+		_, err := services.NewStripeGateway(map[string]interface{}{})
+		var configErr *services.InvalidConfigError
+		require.ErrorAs(t, err, &configErr)
+
+		_, err = services.NewStripeGateway(map[string]interface{}{"api_key": "pk_test_123"})
+		assert.ErrorAs(t, err, &configErr)
+	})
+
+	t.Run("should create a stripe gateway from a secret key", func(t *testing.T) {
+		gateway, err := services.GetFactory().CreateGateway("stripe", map[string]interface{}{"api_key": "sk_test_123"})
+		require.NoError(t, err)
+		assert.Equal(t, "stripe", gateway.GetProvider())
+		assert.True(t, gateway.GetCapabilities().SupportsDisputes)
+	})
+}
@@ -0,0 +1,220 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"apis/payments/services/graphql"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGraphQL tests executing GraphQL queries and batching their lookups
+// with dataloaders
+func TestGraphQL(t *testing.T) {
+	ctx := context.Background()
+	config := &graphql.Config{MaxDepth: 4, LoaderWait: 5 * time.Millisecond, LoaderConcurrency: 4}
+
+	owners := map[string]*GraphQLOwner{
+		"own_1": {ID: "own_1", Name: "Ada", Metadata: map[string]string{"tier": "gold"}},
+		"own_2": {ID: "own_2", Name: "Grace"},
+	}
+	items := []*GraphQLItem{
+		{ID: "it_1", OwnerID: "own_1", Amount: 100},
+		{ID: "it_2", OwnerID: "own_2", Amount: 200},
+		{ID: "it_3", OwnerID: "own_1", Amount: 300},
+	}
+
+	setup := func() (*graphql.Schema, *MockGraphQLOwnerSource) {
+		source := &MockGraphQLOwnerSource{owners: owners}
+		loader := graphql.NewLoader(source.fetch, config.LoaderWait)
+
+		ownerType := &graphql.Object{Name: "Owner", Fields: graphql.Scalars("id", "name", "metadata")}
+		itemType := &graphql.Object{Name: "Item", Fields: graphql.Scalars("id", "ownerId", "amount")}
+		itemType.Fields["owner"] = &graphql.Field{Type: ownerType, Resolve: func(ctx context.Context, source any, args graphql.Args) (any, error) {
+			return loader.Load(ctx, source.(*GraphQLItem).OwnerID)
+		}}
+		ownerType.Fields["items"] = &graphql.Field{Type: itemType, Resolve: func(ctx context.Context, source any, args graphql.Args) (any, error) {
+			var owned []*GraphQLItem
+			for _, it := range items {
+				if it.OwnerID == source.(*GraphQLOwner).ID && len(owned) < args.Int("limit", 10) {
+					owned = append(owned, it)
+				}
+			}
+			return owned, nil
+		}}
+
+		query := &graphql.Object{Name: "Query", Fields: map[string]*graphql.Field{
+			"items": {Type: itemType, Resolve: func(ctx context.Context, source any, args graphql.Args) (any, error) {
+				return items, nil
+			}},
+			"owner": {Type: ownerType, Resolve: func(ctx context.Context, source any, args graphql.Args) (any, error) {
+				return loader.Load(ctx, args.String("id"))
+			}},
+			"failing": {Resolve: func(ctx context.Context, source any, args graphql.Args) (any, error) {
+				return nil, errors.New("provider unavailable")
+			}},
+		}}
+		return graphql.NewSchema(query, config), source
+	}
+
+	execute := func(t *testing.T, schema *graphql.Schema, request graphql.Request) (string, []*graphql.Error) {
+		response := schema.Execute(ctx, request)
+		data, err := json.Marshal(response.Data)
+		require.NoError(t, err)
+		return string(data), response.Errors
+	}
+
+	t.Run("should return the selected fields in order", func(t *testing.T) {
+		schema, _ := setup()
+
+		data, errs := execute(t, schema, graphql.Request{Query: `{ owner(id: "own_1") { name id metadata __typename } }`})
+		assert.Empty(t, errs)
+		assert.Equal(t, `{"owner":{"name":"Ada","id":"own_1","metadata":{"tier":"gold"},"__typename":"Owner"}}`, data)
+	})
+
+	t.Run("should apply variables, aliases, fragments and directives", func(t *testing.T) {
+		schema, _ := setup()
+
+		data, errs := execute(t, schema, graphql.Request{
+			Query: `
+				query Owner($id: ID!, $limit: Int = 5, $withName: Boolean!) {
+					first: owner(id: $id) { ...ownerFields name @include(if: $withName) }
+				}
+				fragment ownerFields on Owner { id items(limit: $limit) { ... on Item { amount } } }`,
+			OperationName: "Owner",
+			Variables:     map[string]any{"id": "own_1", "limit": float64(1), "withName": false},
+		})
+		assert.Empty(t, errs)
+		assert.JSONEq(t, `{"first":{"id":"own_1","items":[{"amount":100}]}}`, data)
+	})
+
+	t.Run("should batch and deduplicate lookups of sibling fields", func(t *testing.T) {
+		schema, source := setup()
+
+		data, errs := execute(t, schema, graphql.Request{Query: `{ items { id owner { name } } }`})
+		assert.Empty(t, errs)
+		assert.JSONEq(t, `{"items":[
+			{"id":"it_1","owner":{"name":"Ada"}},
+			{"id":"it_2","owner":{"name":"Grace"}},
+			{"id":"it_3","owner":{"name":"Ada"}}
+		]}`, data)
+		assert.Equal(t, [][]string{{"own_1", "own_2"}}, source.sortedBatches())
+	})
+
+	t.Run("should null failed fields and report them with their path", func(t *testing.T) {
+		schema, _ := setup()
+
+		data, errs := execute(t, schema, graphql.Request{Query: `{ failing owner(id: "own_9") { name } items { id } }`})
+		assert.Contains(t, data, `"failing":null`)
+		assert.Contains(t, data, `"owner":null`)
+		assert.Contains(t, data, `"items":[{"id":"it_1"}`)
+		require.Len(t, errs, 2)
+		paths := []any{errs[0].Path, errs[1].Path}
+		assert.ElementsMatch(t, []any{[]any{"failing"}, []any{"owner"}}, paths)
+	})
+
+	t.Run("should reject queries that don't match the schema", func(t *testing.T) {
+		schema, _ := setup()
+
+		cases := map[string]string{
+			`{ owner(id: "own_1") { email } }`:                         `type Owner has no field "email"`,
+			`{ owner(id: "own_1") }`:                                   `must select subfields`,
+			`{ failing { id } }`:                                       `cannot select subfields`,
+			`{ owner(id: "own_1") { ...missing } }`:                    `unknown fragment "missing"`,
+			`{ items { owner { items { owner { items { id } } } } } }`: `deeper than 4`,
+			`mutation { items { id } }`:                                `only queries are supported`,
+			`{ owner(id: "own_1" { id } }`:                             `syntax error`,
+		}
+		for query, message := range cases {
+			response := schema.Execute(ctx, graphql.Request{Query: query})
+			assert.Nil(t, response.Data, query)
+			require.Len(t, response.Errors, 1, query)
+			assert.Contains(t, response.Errors[0].Message, message, query)
+		}
+
+		response := schema.Execute(ctx, graphql.Request{Query: `query Owner($id: ID!) { owner(id: $id) { id } }`})
+		require.Len(t, response.Errors, 1)
+		assert.Contains(t, response.Errors[0].Message, "variable $id is required")
+	})
+
+	t.Run("should fetch each key once from sources without batch lookups", func(t *testing.T) {
+		var mu sync.Mutex
+		var fetched []string
+		loader := graphql.NewLoader(graphql.PerKey(func(ctx context.Context, key string) (string, error) {
+			mu.Lock()
+			fetched = append(fetched, key)
+			mu.Unlock()
+			if key == "bad" {
+				return "", errors.New("not found")
+			}
+			return "value of " + key, nil
+		}, 2), time.Millisecond)
+
+		values, err := loader.LoadMany(ctx, []string{"a", "b", "a"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"value of a", "value of b", "value of a"}, values)
+
+		_, err = loader.Load(ctx, "bad")
+		assert.EqualError(t, err, "not found")
+
+		value, err := loader.Load(ctx, "b")
+		require.NoError(t, err)
+		assert.Equal(t, "value of b", value)
+		assert.ElementsMatch(t, []string{"a", "b", "bad"}, fetched, "loaded values are cached")
+	})
+}
+
+// GraphQLOwner is a source value of the test schema
+type GraphQLOwner struct {
+	ID       string            `json:"id"`
+	Name     string            `json:"name"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// GraphQLItem is a source value of the test schema, linked to its owner
+type GraphQLItem struct {
+	ID      string `json:"id"`
+	OwnerID string `json:"owner_id"`
+	Amount  int64  `json:"amount"`
+}
+
+// MockGraphQLOwnerSource looks up owners in batches, recording each batch
+type MockGraphQLOwnerSource struct {
+	mu      sync.Mutex
+	owners  map[string]*GraphQLOwner
+	batches [][]string
+}
+
+func (m *MockGraphQLOwnerSource) fetch(ctx context.Context, keys []string) ([]*GraphQLOwner, []error) {
+	m.mu.Lock()
+	m.batches = append(m.batches, keys)
+	m.mu.Unlock()
+
+	values := make([]*GraphQLOwner, len(keys))
+	errs := make([]error, len(keys))
+	for i, key := range keys {
+		if values[i] = m.owners[key]; values[i] == nil {
+			errs[i] = errors.New("owner not found")
+		}
+	}
+	return values, errs
+}
+
+func (m *MockGraphQLOwnerSource) sortedBatches() [][]string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	batches := make([][]string, len(m.batches))
+	for i, batch := range m.batches {
+		batches[i] = append([]string(nil), batch...)
+		sort.Strings(batches[i])
+	}
+	return batches
+}
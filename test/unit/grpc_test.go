@@ -0,0 +1,484 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	paymentsv1 "apis/payments/proto/payments/v1"
+	"apis/payments/services"
+	"apis/payments/services/audit"
+	"apis/payments/services/auth"
+	"apis/payments/services/customerbalance"
+	"apis/payments/services/disputes"
+	"apis/payments/services/grpcserver"
+	"apis/payments/services/holds"
+	"apis/payments/services/quarantine"
+	"apis/payments/services/ratelimit"
+	"apis/payments/services/refundguard"
+	"apis/payments/services/tenancy"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// TestGRPC tests the gRPC API's authentication, tenant and provider binding
+// and delegation to tenant gateways
+func TestGRPC(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T, configure ...func(*grpcserver.Service)) (paymentsv1.PaymentsServiceClient, *MockGRPCGateways) {
+		gateways := &MockGRPCGateways{gateway: &MockGRPCGateway{charges: make(map[string]*services.Charge)}}
+		authenticator := &MockGRPCAuthenticator{principals: map[string]*auth.Principal{
+			"psk_read":  {Type: auth.PrincipalAPIKey, ID: "key_read", Scopes: []string{auth.ScopeRead}},
+			"psk_write": {Type: auth.PrincipalAPIKey, ID: "key_write", Scopes: []string{auth.ScopeChargesWrite}},
+			"psk_acme":  {Type: auth.PrincipalAPIKey, ID: "key_acme", TenantID: "acme", Scopes: []string{auth.ScopeAdmin}},
+		}}
+		tenants := tenancy.NewService(NewMockTenantConfigStore(), &tenancy.Config{CacheTTL: time.Minute})
+
+		listener := bufconn.Listen(1 << 20)
+		service := grpcserver.NewService(gateways, authenticator, tenants)
+		for _, apply := range configure {
+			apply(service)
+		}
+		server := grpcserver.NewServer(service)
+		go server.Serve(listener)
+		t.Cleanup(server.Stop)
+
+		conn, err := grpc.NewClient("passthrough:///bufnet",
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+				return listener.DialContext(ctx)
+			}),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+		)
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+
+		return paymentsv1.NewPaymentsServiceClient(conn), gateways
+	}
+
+	withKey := func(secret string, pairs ...string) context.Context {
+		return metadata.AppendToOutgoingContext(ctx, append([]string{"authorization", "Bearer " + secret}, pairs...)...)
+	}
+
+	t.Run("should require the scope each method needs", func(t *testing.T) {
+		assert.Equal(t, auth.ScopeRead, grpcserver.RequiredScope(paymentsv1.PaymentsService_ListCharges_FullMethodName))
+		assert.Equal(t, auth.ScopeChargesWrite, grpcserver.RequiredScope(paymentsv1.PaymentsService_CaptureCharge_FullMethodName))
+		assert.Equal(t, auth.ScopeRefundsWrite, grpcserver.RequiredScope(paymentsv1.PaymentsService_CreateRefund_FullMethodName))
+		assert.Equal(t, auth.ScopeWrite, grpcserver.RequiredScope(paymentsv1.PaymentsService_CancelSubscription_FullMethodName))
+
+		client, _ := setup(t)
+
+		_, err := client.GetCharge(ctx, &paymentsv1.GetChargeRequest{Id: "ch_1"})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+		_, err = client.CreateCharge(withKey("psk_read"), &paymentsv1.CreateChargeRequest{Amount: 1000, Currency: "usd"})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("should create and get charges through the tenant's gateway", func(t *testing.T) {
+		client, gateways := setup(t)
+
+		charge, err := client.CreateCharge(withKey("psk_write", "x-tenant-id", "globex"), &paymentsv1.CreateChargeRequest{
+			Amount:   1500,
+			Currency: "usd",
+			Metadata: map[string]string{"order_id": "ord_1"},
+			Capture:  true,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1500), charge.Amount)
+		assert.Equal(t, "ord_1", charge.Metadata["order_id"])
+		assert.NotNil(t, charge.CreatedAt)
+		assert.Equal(t, "globex", gateways.tenantID)
+		assert.Equal(t, "stripe", gateways.provider)
+		assert.Equal(t, "globex", gateways.gateway.tenantID)
+
+		fetched, err := client.GetCharge(withKey("psk_read"), &paymentsv1.GetChargeRequest{Id: charge.Id})
+		require.NoError(t, err)
+		assert.Equal(t, charge.Id, fetched.Id)
+		assert.Equal(t, tenancy.DefaultTenantID, gateways.tenantID)
+
+		_, err = client.GetCharge(withKey("psk_read"), &paymentsv1.GetChargeRequest{})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("should serve calls through the provider they select", func(t *testing.T) {
+		client, gateways := setup(t)
+
+		_, err := client.CreateCharge(withKey("psk_write", "x-payment-provider", "Paddle"), &paymentsv1.CreateChargeRequest{
+			Amount:   1500,
+			Currency: "eur",
+			Capture:  true,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "paddle", gateways.provider)
+
+		_, err = client.GetCharge(withKey("psk_read"), &paymentsv1.GetChargeRequest{Id: "ch_grpc"})
+		require.NoError(t, err)
+		assert.Equal(t, "stripe", gateways.provider, "calls naming no provider use the tenant's default")
+
+		_, err = client.ListCharges(withKey("psk_read", "x-payment-provider", "adyen"), &paymentsv1.ListChargesRequest{})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("should bind tenant keys to their tenant", func(t *testing.T) {
+		client, gateways := setup(t)
+
+		_, err := client.ListCharges(withKey("psk_acme"), &paymentsv1.ListChargesRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, "acme", gateways.tenantID)
+
+		_, err = client.ListCharges(withKey("psk_acme", "x-tenant-id", "globex"), &paymentsv1.ListChargesRequest{})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("should report disputes as unimplemented for gateways without them", func(t *testing.T) {
+		client, _ := setup(t)
+
+		_, err := client.GetDispute(withKey("psk_read"), &paymentsv1.GetDisputeRequest{Id: "dp_1"})
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+	})
+
+	t.Run("should run charges through the charge policy", func(t *testing.T) {
+		policy := &MockGRPCChargePolicy{}
+		client, gateways := setup(t, func(service *grpcserver.Service) { service.UseChargePolicy(policy) })
+
+		policy.err = fmt.Errorf("%w: chargeback hold hold_1", holds.ErrCustomerOnHold)
+		_, err := client.CreateCharge(withKey("psk_write"), &paymentsv1.CreateChargeRequest{Amount: 1500, Currency: "usd", CustomerId: "cus_1"})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		assert.Empty(t, gateways.gateway.charges, "refused charges never reach the gateway")
+
+		policy.err = grpcserver.ErrChargeNeedsReview
+		_, err = client.CreateCharge(withKey("psk_write"), &paymentsv1.CreateChargeRequest{Amount: 1500, Currency: "usd", CustomerId: "cus_1"})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+		policy.err = nil
+		policy.credit = 500
+		charge, err := client.CreateCharge(withKey("psk_write"), &paymentsv1.CreateChargeRequest{Amount: 1500, Currency: "usd", CustomerId: "cus_1", Capture: true})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1000), charge.Amount, "credit is spent before the payment method is charged")
+		assert.Equal(t, "ch_grpc", policy.recorded)
+
+		policy.credit = 1500
+		charge, err = client.CreateCharge(withKey("psk_write"), &paymentsv1.CreateChargeRequest{Amount: 1500, Currency: "usd", CustomerId: "cus_1"})
+		require.NoError(t, err)
+		assert.Equal(t, "customer_balance", charge.Provider)
+		assert.Equal(t, "cbtx_1", charge.Id)
+		assert.Equal(t, int64(1500), charge.Amount)
+	})
+
+	t.Run("should issue refunds through the refund policy", func(t *testing.T) {
+		policy := &MockGRPCRefundPolicy{}
+		client, _ := setup(t, func(service *grpcserver.Service) { service.UseRefundPolicy(policy) })
+
+		refund, err := client.CreateRefund(withKey("psk_acme", "x-operator-id", "op_1"), &paymentsv1.CreateRefundRequest{ChargeId: "ch_1", Amount: 500})
+		require.NoError(t, err)
+		assert.Equal(t, "re_grpc", refund.Id)
+		assert.Equal(t, refundguard.Actor{TenantID: "acme", APIKeyID: auth.Fingerprint("psk_acme"), OperatorID: "op_1"}, policy.actor)
+		assert.Equal(t, int64(500), policy.request.Amount)
+
+		policy.approval = &refundguard.Approval{ID: "rfa_1", Status: refundguard.ApprovalPending}
+		_, err = client.CreateRefund(withKey("psk_acme"), &paymentsv1.CreateRefundRequest{ChargeId: "ch_1"})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.Contains(t, status.Convert(err).Message(), "rfa_1", "held refunds name their approval")
+
+		policy.approval = nil
+		policy.err = fmt.Errorf("%w: charge ch_1 is past the refund window", refundguard.ErrRefundPolicy)
+		_, err = client.CreateRefund(withKey("psk_acme"), &paymentsv1.CreateRefundRequest{ChargeId: "ch_1"})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+
+	t.Run("should answer disputes through the dispute policy", func(t *testing.T) {
+		policy := &MockGRPCDisputePolicy{}
+		client, gateways := setup(t, func(service *grpcserver.Service) { service.UseDisputePolicy(policy) })
+		gateways.disputes = &MockGRPCDisputeGateway{MockGRPCGateway: gateways.gateway}
+
+		dispute, err := client.SubmitDisputeEvidence(withKey("psk_acme", "x-operator-id", "op_1"), &paymentsv1.SubmitDisputeEvidenceRequest{
+			Id:       "dp_1",
+			Evidence: []*paymentsv1.DisputeEvidence{{Type: "uncategorized_text", Text: "Delivered"}},
+			Submit:   true,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "dp_1", dispute.Id)
+		assert.Equal(t, map[string]string{"uncategorized_text": "Delivered"}, policy.staged)
+		assert.Equal(t, "op_1", policy.submittedBy)
+
+		policy.err = fmt.Errorf("%w: dispute is lost", disputes.ErrDisputeClosed)
+		_, err = client.AcceptDispute(withKey("psk_acme"), &paymentsv1.AcceptDisputeRequest{Id: "dp_1"})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.Empty(t, gateways.disputes.accepted, "disputes are never conceded at the gateway directly")
+	})
+
+	t.Run("should rate limit calls as their REST routes", func(t *testing.T) {
+		limiter := &MockGRPCRateLimiter{allowed: false}
+		client, _ := setup(t, func(service *grpcserver.Service) { service.UseRateLimits(limiter) })
+
+		var header metadata.MD
+		_, err := client.GetCharge(withKey("psk_read"), &paymentsv1.GetChargeRequest{Id: "ch_1"}, grpc.Header(&header))
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+		assert.Equal(t, "api_key:key_read", limiter.subject)
+		assert.Equal(t, "GET /charges/ch_1", limiter.endpoint)
+		assert.Equal(t, []string{"2"}, header.Get("retry-after"))
+		assert.Equal(t, []string{"0"}, header.Get("x-ratelimit-remaining"))
+	})
+
+	t.Run("should refuse mutations from quarantined callers and let reads through", func(t *testing.T) {
+		store := NewMockQuarantineStore()
+		gate := quarantine.NewService(store, &MockReplayer{})
+		_, err := gate.Quarantine(ctx, &quarantine.Quarantine{SubjectType: quarantine.SubjectTenant, SubjectID: "acme"})
+		require.NoError(t, err)
+		client, gateways := setup(t, func(service *grpcserver.Service) { service.UseQuarantine(gate) })
+
+		_, err = client.ListCharges(withKey("psk_acme"), &paymentsv1.ListChargesRequest{})
+		require.NoError(t, err)
+
+		_, err = client.CreateCharge(withKey("psk_acme"), &paymentsv1.CreateChargeRequest{Amount: 1500, Currency: "usd"})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		assert.Empty(t, gateways.gateway.charges)
+
+		require.Len(t, store.activity, 2)
+		assert.Equal(t, paymentsv1.PaymentsService_CreateCharge_FullMethodName, store.activity[1].Path)
+		assert.Equal(t, auth.Fingerprint("psk_acme"), store.activity[1].APIKeyID)
+		assert.Empty(t, store.mutations, "gRPC mutations can't be replayed, so none are held")
+	})
+
+	t.Run("should audit successful mutations as their REST routes", func(t *testing.T) {
+		auditLog := &MockGRPCAuditLog{}
+		client, _ := setup(t, func(service *grpcserver.Service) { service.UseAuditLog(auditLog) })
+
+		_, err := client.ListCharges(withKey("psk_acme"), &paymentsv1.ListChargesRequest{})
+		require.NoError(t, err)
+		_, err = client.CreateCharge(withKey("psk_acme"), &paymentsv1.CreateChargeRequest{Amount: 1500, Currency: "usd", Capture: true})
+		require.NoError(t, err)
+
+		require.Len(t, auditLog.calls, 1, "reads aren't audited")
+		call := auditLog.calls[0]
+		assert.Equal(t, "POST", call.Method)
+		assert.Equal(t, "/charges", call.Route)
+		assert.Equal(t, "acme", call.TenantID)
+		assert.Equal(t, auth.PrincipalAPIKey, call.ActorType)
+		assert.Equal(t, "key_acme", call.ActorID)
+		var response map[string]any
+		require.NoError(t, json.Unmarshal(call.Response, &response))
+		assert.Equal(t, "ch_grpc", response["id"])
+	})
+
+	t.Run("should be off by default and require TLS", func(t *testing.T) {
+		t.Setenv("GRPC_ENABLED", "")
+		config := grpcserver.LoadConfig()
+		assert.False(t, config.Enabled)
+
+		_, err := config.Credentials()
+		assert.ErrorIs(t, err, grpcserver.ErrTLSRequired)
+	})
+}
+
+// MockGRPCChargePolicy refuses charges with err, or spends credit on them
+type MockGRPCChargePolicy struct {
+	err      error
+	credit   int64
+	recorded string
+}
+
+func (m *MockGRPCChargePolicy) Admit(ctx context.Context, tenantID string, request *services.CreateChargeRequest) (*customerbalance.Application, error) {
+	if m.err != nil || m.credit == 0 {
+		return nil, m.err
+	}
+	applied := min(m.credit, request.Amount)
+	request.Amount -= applied
+	return &customerbalance.Application{
+		Applied:     applied,
+		Remaining:   request.Amount,
+		Transaction: &customerbalance.Transaction{ID: "cbtx_1", Amount: -applied, CreatedAt: time.Now()},
+	}, nil
+}
+
+func (m *MockGRPCChargePolicy) Record(ctx context.Context, tenantID string, application *customerbalance.Application, charge *services.Charge) {
+	m.recorded = charge.ID
+}
+
+func (m *MockGRPCChargePolicy) Reverse(ctx context.Context, application *customerbalance.Application) {
+}
+
+// MockGRPCRefundPolicy issues refunds, holds them for approval or refuses
+// them with err, recording the last actor and request
+type MockGRPCRefundPolicy struct {
+	err      error
+	approval *refundguard.Approval
+	actor    refundguard.Actor
+	request  services.CreateRefundRequest
+}
+
+func (m *MockGRPCRefundPolicy) CreateRefund(ctx context.Context, actor refundguard.Actor, request services.CreateRefundRequest) (*services.Refund, *refundguard.Approval, error) {
+	m.actor, m.request = actor, request
+	if m.err != nil || m.approval != nil {
+		return nil, m.approval, m.err
+	}
+	return &services.Refund{ID: "re_grpc", ChargeID: request.ChargeID, Amount: request.Amount, CreatedAt: time.Now()}, nil, nil
+}
+
+// MockGRPCDisputePolicy records staged and submitted evidence, or refuses
+// every call with err
+type MockGRPCDisputePolicy struct {
+	err         error
+	staged      map[string]string
+	submittedBy string
+}
+
+func (m *MockGRPCDisputePolicy) StageEvidence(ctx context.Context, disputeID string, fields map[string]string, operatorID string) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.staged = fields
+	return nil
+}
+
+func (m *MockGRPCDisputePolicy) SubmitEvidence(ctx context.Context, disputeID, operatorID string) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.submittedBy = operatorID
+	return nil
+}
+
+func (m *MockGRPCDisputePolicy) AcceptDispute(ctx context.Context, disputeID, operatorID string) error {
+	return m.err
+}
+
+// MockGRPCRateLimiter allows or denies every call, recording the last one
+type MockGRPCRateLimiter struct {
+	allowed  bool
+	subject  string
+	endpoint string
+}
+
+func (m *MockGRPCRateLimiter) Enabled() bool {
+	return true
+}
+
+func (m *MockGRPCRateLimiter) Allow(ctx context.Context, subject, method, path string) (*ratelimit.Decision, error) {
+	m.subject = subject
+	m.endpoint = method + " " + path
+	decision := &ratelimit.Decision{Allowed: m.allowed, Limit: 10, Reset: time.Second}
+	if !m.allowed {
+		decision.RetryAfter = 2 * time.Second
+	}
+	return decision, nil
+}
+
+// MockGRPCAuditLog keeps the calls it is asked to record
+type MockGRPCAuditLog struct {
+	calls []*audit.Call
+}
+
+func (m *MockGRPCAuditLog) Record(ctx context.Context, call *audit.Call) (*audit.Entry, error) {
+	m.calls = append(m.calls, call)
+	return &audit.Entry{}, nil
+}
+
+// MockGRPCGateways returns one gateway for every tenant, recording the last
+// tenant and provider asked for
+type MockGRPCGateways struct {
+	gateway  *MockGRPCGateway
+	disputes *MockGRPCDisputeGateway // Served instead of gateway when set
+	tenantID string
+	provider string
+}
+
+func (m *MockGRPCGateways) Gateway(ctx context.Context, tenantID, provider string) (services.PaymentGateway, error) {
+	m.tenantID = tenantID
+	m.provider = provider
+	if m.disputes != nil {
+		return m.disputes, nil
+	}
+	return m.gateway, nil
+}
+
+// MockGRPCGateway implements the charge calls of a gateway; the embedded
+// interface panics on anything else
+type MockGRPCGateway struct {
+	services.PaymentGateway
+	charges  map[string]*services.Charge
+	tenantID string
+}
+
+func (m *MockGRPCGateway) GetProvider() string {
+	return "mock"
+}
+
+func (m *MockGRPCGateway) CreateCharge(ctx context.Context, req services.CreateChargeRequest) (*services.Charge, error) {
+	m.tenantID = tenancy.ID(ctx)
+	charge := &services.Charge{
+		ID:        "ch_grpc",
+		Amount:    req.Amount,
+		Currency:  req.Currency,
+		Status:    "succeeded",
+		Metadata:  req.Metadata,
+		CreatedAt: time.Now(),
+		Provider:  "mock",
+	}
+	m.charges[charge.ID] = charge
+	return charge, nil
+}
+
+func (m *MockGRPCGateway) GetCharge(ctx context.Context, chargeID string) (*services.Charge, error) {
+	return m.charges[chargeID], nil
+}
+
+func (m *MockGRPCGateway) ListCharges(ctx context.Context, req services.ListChargesRequest) (*services.ChargeList, error) {
+	list := &services.ChargeList{}
+	for _, charge := range m.charges {
+		list.Charges = append(list.Charges, charge)
+	}
+	return list, nil
+}
+
+// MockGRPCDisputeGateway adds disputes to a gateway, recording disputes
+// accepted at it
+type MockGRPCDisputeGateway struct {
+	*MockGRPCGateway
+	accepted []string
+}
+
+func (m *MockGRPCDisputeGateway) GetDispute(ctx context.Context, disputeID string) (*services.Dispute, error) {
+	return &services.Dispute{ID: disputeID, ChargeID: "ch_1", Status: "under_review", CreatedAt: time.Now()}, nil
+}
+
+func (m *MockGRPCDisputeGateway) ListDisputes(ctx context.Context, req services.ListDisputesRequest) (*services.DisputeList, error) {
+	return &services.DisputeList{}, nil
+}
+
+func (m *MockGRPCDisputeGateway) AcceptDispute(ctx context.Context, disputeID string) (*services.Dispute, error) {
+	m.accepted = append(m.accepted, disputeID)
+	return &services.Dispute{ID: disputeID, Status: "lost"}, nil
+}
+
+func (m *MockGRPCDisputeGateway) SubmitDisputeEvidence(ctx context.Context, disputeID string, req services.SubmitDisputeEvidenceRequest) (*services.Dispute, error) {
+	return &services.Dispute{ID: disputeID, Status: "under_review"}, nil
+}
+
+// MockGRPCAuthenticator authenticates a fixed set of secrets
+type MockGRPCAuthenticator struct {
+	principals map[string]*auth.Principal
+}
+
+func (m *MockGRPCAuthenticator) Enabled() bool {
+	return true
+}
+
+func (m *MockGRPCAuthenticator) Authenticate(ctx context.Context, credential string) (*auth.Principal, error) {
+	principal, ok := m.principals[credential]
+	if !ok {
+		return nil, auth.ErrUnauthenticated
+	}
+	return principal, nil
+}
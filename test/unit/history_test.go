@@ -0,0 +1,139 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sort"
+	"testing"
+	"time"
+
+	"apis/payments/services/history"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEntityHistory tests recording entity versions and as-of queries
+func TestEntityHistory(t *testing.T) {
+	ctx := context.Background()
+	pending := json.RawMessage(`{"id":"ch_1","status":"pending"}`)
+	succeeded := json.RawMessage(`{"id":"ch_1","status":"succeeded"}`)
+
+	t.Run("should record versions ordered by when the change happened", func(t *testing.T) {
+		store := NewMockHistoryStore()
+		service := history.NewService(store)
+
+		// Deliveries arrive out of order
+		require.NoError(t, service.Record(ctx, history.EntityCharge, "evt_2", "charge.succeeded", 1700000100, succeeded))
+		require.NoError(t, service.Record(ctx, history.EntityCharge, "evt_1", "charge.pending", 1700000000, pending))
+
+		versions, err := service.History(ctx, history.EntityCharge, "ch_1")
+		require.NoError(t, err)
+		require.Len(t, versions, 2)
+		assert.Equal(t, 1, versions[0].Version)
+		assert.Equal(t, "pending", versions[0].Status)
+		assert.Equal(t, 2, versions[1].Version)
+		assert.Equal(t, "succeeded", versions[1].Status)
+		assert.Equal(t, "evt_2", versions[1].EventID)
+	})
+
+	t.Run("should ignore redelivered events", func(t *testing.T) {
+		store := NewMockHistoryStore()
+		service := history.NewService(store)
+
+		require.NoError(t, service.Record(ctx, history.EntityCharge, "evt_1", "charge.pending", 1700000000, pending))
+		require.NoError(t, service.Record(ctx, history.EntityCharge, "evt_1", "charge.pending", 1700000000, pending))
+
+		versions, err := service.History(ctx, history.EntityCharge, "ch_1")
+		require.NoError(t, err)
+		assert.Len(t, versions, 1)
+	})
+
+	t.Run("should return the version current at a point in time", func(t *testing.T) {
+		store := NewMockHistoryStore()
+		service := history.NewService(store)
+		require.NoError(t, service.Record(ctx, history.EntityCharge, "evt_1", "charge.pending", 1700000000, pending))
+		require.NoError(t, service.Record(ctx, history.EntityCharge, "evt_2", "charge.succeeded", 1700000100, succeeded))
+
+		version, err := service.AsOf(ctx, history.EntityCharge, "ch_1", time.Unix(1700000050, 0))
+		require.NoError(t, err)
+		assert.Equal(t, "pending", version.Status)
+
+		version, err = service.AsOf(ctx, history.EntityCharge, "ch_1", time.Unix(1700000100, 0))
+		require.NoError(t, err)
+		assert.Equal(t, "succeeded", version.Status)
+	})
+
+	t.Run("should return ErrNoVersion before the entity existed", func(t *testing.T) {
+		store := NewMockHistoryStore()
+		service := history.NewService(store)
+		require.NoError(t, service.Record(ctx, history.EntityCharge, "evt_1", "charge.pending", 1700000000, pending))
+
+		_, err := service.AsOf(ctx, history.EntityCharge, "ch_1", time.Unix(1600000000, 0))
+		assert.ErrorIs(t, err, history.ErrNoVersion)
+	})
+
+	t.Run("should reject state without an ID", func(t *testing.T) {
+		service := history.NewService(NewMockHistoryStore())
+
+		err := service.Record(ctx, history.EntityDispute, "evt_1", "charge.dispute.created", 1700000000, json.RawMessage(`{"status":"needs_response"}`))
+		assert.Error(t, err)
+	})
+
+	t.Run("should reject unknown entity types", func(t *testing.T) {
+		service := history.NewService(NewMockHistoryStore())
+
+		_, err := service.History(ctx, "invoice", "in_1")
+		assert.Error(t, err)
+	})
+}
+
+// MockHistoryStore is an in-memory history.Store
+type MockHistoryStore struct {
+	versions []*history.Version
+}
+
+func NewMockHistoryStore() *MockHistoryStore {
+	return &MockHistoryStore{}
+}
+
+func (m *MockHistoryStore) RecordEntityVersion(ctx context.Context, version *history.Version) error {
+	for _, existing := range m.versions {
+		if existing.EntityType == version.EntityType && existing.EntityID == version.EntityID && existing.EventID == version.EventID {
+			return nil
+		}
+	}
+	stored := *version
+	stored.ID = int64(len(m.versions) + 1)
+	m.versions = append(m.versions, &stored)
+	return nil
+}
+
+func (m *MockHistoryStore) ListEntityVersions(ctx context.Context, entityType, entityID string) ([]*history.Version, error) {
+	var versions []*history.Version
+	for _, version := range m.versions {
+		if version.EntityType == entityType && version.EntityID == entityID {
+			copied := *version
+			versions = append(versions, &copied)
+		}
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].ValidFrom.Before(versions[j].ValidFrom)
+	})
+	return versions, nil
+}
+
+func (m *MockHistoryStore) GetEntityVersionAsOf(ctx context.Context, entityType, entityID string, asOf time.Time) (*history.Version, error) {
+	versions, _ := m.ListEntityVersions(ctx, entityType, entityID)
+	var current *history.Version
+	for _, version := range versions {
+		if !version.ValidFrom.After(asOf) {
+			current = version
+		}
+	}
+	if current == nil {
+		return nil, sql.ErrNoRows
+	}
+	return current, nil
+}
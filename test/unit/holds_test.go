@@ -0,0 +1,236 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"apis/payments/services/holds"
+	"apis/payments/services/stripe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCustomerHolds tests pausing subscriptions and blocking charges on disputes and fraud flags
+func TestCustomerHolds(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should pause subscriptions and block charges when a dispute opens", func(t *testing.T) {
+		store := NewMockHoldStore()
+		subscriptions := NewMockSubscriptionManager("cus_test123", "sub_1", "sub_2")
+		service := holds.NewService(store, subscriptions)
+
+		hold, err := service.HandleDisputeOpened(ctx, holds.DefaultTenantID, "cus_test123", "dp_123")
+
+		require.NoError(t, err)
+		require.NotNil(t, hold)
+		assert.Equal(t, holds.ReasonDispute, hold.Reason)
+		assert.ElementsMatch(t, []string{"sub_1", "sub_2"}, hold.PausedSubscriptions)
+		assert.True(t, subscriptions.paused["sub_1"])
+		assert.True(t, subscriptions.paused["sub_2"])
+
+		err = service.CheckCharge(ctx, "cus_test123")
+		assert.ErrorIs(t, err, holds.ErrCustomerOnHold)
+	})
+
+	t.Run("should unpause subscriptions when a dispute is won", func(t *testing.T) {
+		store := NewMockHoldStore()
+		subscriptions := NewMockSubscriptionManager("cus_test123", "sub_1")
+		service := holds.NewService(store, subscriptions)
+
+		_, err := service.HandleDisputeOpened(ctx, holds.DefaultTenantID, "cus_test123", "dp_123")
+		require.NoError(t, err)
+
+		err = service.HandleDisputeClosed(ctx, holds.DefaultTenantID, "dp_123", "won")
+		require.NoError(t, err)
+
+		assert.False(t, subscriptions.paused["sub_1"])
+		assert.NoError(t, service.CheckCharge(ctx, "cus_test123"))
+	})
+
+	t.Run("should keep the hold when a dispute is lost", func(t *testing.T) {
+		store := NewMockHoldStore()
+		subscriptions := NewMockSubscriptionManager("cus_test123", "sub_1")
+		service := holds.NewService(store, subscriptions)
+
+		_, err := service.HandleDisputeOpened(ctx, holds.DefaultTenantID, "cus_test123", "dp_123")
+		require.NoError(t, err)
+
+		err = service.HandleDisputeClosed(ctx, holds.DefaultTenantID, "dp_123", "lost")
+		require.NoError(t, err)
+
+		assert.True(t, subscriptions.paused["sub_1"])
+		assert.ErrorIs(t, service.CheckCharge(ctx, "cus_test123"), holds.ErrCustomerOnHold)
+	})
+
+	t.Run("should ignore fraud flags below the risk threshold", func(t *testing.T) {
+		store := NewMockHoldStore()
+		subscriptions := NewMockSubscriptionManager("cus_test123", "sub_1")
+		service := holds.NewService(store, subscriptions)
+
+		hold, err := service.HandleFraudFlag(ctx, holds.DefaultTenantID, "cus_test123", "prv_123", "elevated", 40)
+
+		require.NoError(t, err)
+		assert.Nil(t, hold)
+		assert.False(t, subscriptions.paused["sub_1"])
+	})
+
+	t.Run("should not stack holds on redelivered events", func(t *testing.T) {
+		store := NewMockHoldStore()
+		subscriptions := NewMockSubscriptionManager("cus_test123", "sub_1")
+		service := holds.NewService(store, subscriptions)
+
+		first, err := service.HandleDisputeOpened(ctx, holds.DefaultTenantID, "cus_test123", "dp_123")
+		require.NoError(t, err)
+		second, err := service.HandleDisputeOpened(ctx, holds.DefaultTenantID, "cus_test123", "dp_123")
+		require.NoError(t, err)
+
+		assert.Equal(t, first.ID, second.ID)
+		customerHolds, err := service.ListHolds(ctx, "cus_test123")
+		require.NoError(t, err)
+		assert.Len(t, customerHolds, 1)
+	})
+
+	t.Run("should keep subscriptions paused while another hold is active", func(t *testing.T) {
+		store := NewMockHoldStore()
+		subscriptions := NewMockSubscriptionManager("cus_test123", "sub_1")
+		service := holds.NewService(store, subscriptions)
+
+		dispute, err := service.HandleDisputeOpened(ctx, holds.DefaultTenantID, "cus_test123", "dp_123")
+		require.NoError(t, err)
+
+		// The fraud hold finds sub_1 already paused, so it does not own it
+		_, err = service.HandleFraudFlag(ctx, holds.DefaultTenantID, "cus_test123", "issfr_123", "highest", 0)
+		require.NoError(t, err)
+
+		_, err = service.ReleaseHold(ctx, dispute.ID, "manual")
+		require.NoError(t, err)
+
+		assert.False(t, subscriptions.paused["sub_1"])
+		assert.ErrorIs(t, service.CheckCharge(ctx, "cus_test123"), holds.ErrCustomerOnHold)
+	})
+}
+
+// MockHoldStore is an in-memory hold store for testing
+type MockHoldStore struct {
+	policies map[string]*holds.Policy
+	holds    []*holds.Hold
+}
+
+// NewMockHoldStore creates an empty in-memory hold store
+func NewMockHoldStore() *MockHoldStore {
+	return &MockHoldStore{policies: make(map[string]*holds.Policy)}
+}
+
+func (m *MockHoldStore) GetHoldPolicy(ctx context.Context, tenantID string) (*holds.Policy, error) {
+	if policy, ok := m.policies[tenantID]; ok {
+		return policy, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *MockHoldStore) UpsertHoldPolicy(ctx context.Context, policy *holds.Policy) (*holds.Policy, error) {
+	m.policies[policy.TenantID] = policy
+	return policy, nil
+}
+
+func (m *MockHoldStore) CreateCustomerHold(ctx context.Context, hold *holds.Hold) (*holds.Hold, error) {
+	hold.CreatedAt = time.Now()
+	m.holds = append(m.holds, hold)
+	return hold, nil
+}
+
+func (m *MockHoldStore) GetCustomerHold(ctx context.Context, id string) (*holds.Hold, error) {
+	for _, hold := range m.holds {
+		if hold.ID == id {
+			return hold, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *MockHoldStore) GetActiveCustomerHoldBySource(ctx context.Context, sourceID string) (*holds.Hold, error) {
+	for _, hold := range m.holds {
+		if hold.SourceID == sourceID && hold.Status == holds.StatusActive {
+			return hold, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *MockHoldStore) ListActiveCustomerHolds(ctx context.Context, customerID string) ([]*holds.Hold, error) {
+	var result []*holds.Hold
+	for _, hold := range m.holds {
+		if hold.CustomerID == customerID && hold.Status == holds.StatusActive {
+			result = append(result, hold)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockHoldStore) ListCustomerHolds(ctx context.Context, customerID string) ([]*holds.Hold, error) {
+	var result []*holds.Hold
+	for _, hold := range m.holds {
+		if hold.CustomerID == customerID {
+			result = append(result, hold)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockHoldStore) ReleaseCustomerHold(ctx context.Context, id, reason string) (*holds.Hold, error) {
+	hold, err := m.GetCustomerHold(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	hold.Status = holds.StatusReleased
+	hold.ReleaseReason = reason
+	hold.ReleasedAt = &now
+	return hold, nil
+}
+
+// MockSubscriptionManager tracks paused subscriptions in memory
+type MockSubscriptionManager struct {
+	customerID string
+	paused     map[string]bool
+}
+
+// NewMockSubscriptionManager creates a subscription manager with active subscriptions for a customer
+func NewMockSubscriptionManager(customerID string, subscriptionIDs ...string) *MockSubscriptionManager {
+	m := &MockSubscriptionManager{customerID: customerID, paused: make(map[string]bool)}
+	for _, id := range subscriptionIDs {
+		m.paused[id] = false
+	}
+	return m
+}
+
+func (m *MockSubscriptionManager) ListActiveSubscriptions(ctx context.Context, customerID string) ([]*stripe.Subscription, error) {
+	if customerID != m.customerID {
+		return nil, nil
+	}
+	var result []*stripe.Subscription
+	for id, paused := range m.paused {
+		result = append(result, &stripe.Subscription{ID: id, CustomerID: customerID, Status: "active", Paused: paused})
+	}
+	return result, nil
+}
+
+func (m *MockSubscriptionManager) PauseSubscription(ctx context.Context, subscriptionID string) (*stripe.Subscription, error) {
+	if _, ok := m.paused[subscriptionID]; !ok {
+		return nil, errors.New("subscription not found")
+	}
+	m.paused[subscriptionID] = true
+	return &stripe.Subscription{ID: subscriptionID, Paused: true}, nil
+}
+
+func (m *MockSubscriptionManager) UnpauseSubscription(ctx context.Context, subscriptionID string) (*stripe.Subscription, error) {
+	if _, ok := m.paused[subscriptionID]; !ok {
+		return nil, errors.New("subscription not found")
+	}
+	m.paused[subscriptionID] = false
+	return &stripe.Subscription{ID: subscriptionID}, nil
+}
@@ -0,0 +1,78 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"apis/payments/services/i18n"
+	"apis/payments/services/stripe"
+
+	"github.com/stretchr/testify/assert"
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// TestTranslator tests localized display messages for errors and declines
+func TestTranslator(t *testing.T) {
+	translator := i18n.NewTranslator()
+
+	t.Run("should negotiate the best supported language", func(t *testing.T) {
+		assert.Equal(t, "fr", translator.Negotiate("fr-CA,fr;q=0.9,en;q=0.8"))
+		assert.Equal(t, "de", translator.Negotiate("ja,de;q=0.7,en;q=0.5"))
+		assert.Equal(t, "en", translator.Negotiate("ja"))
+		assert.Equal(t, "en", translator.Negotiate(""))
+	})
+
+	t.Run("should map decline codes through wrapped errors", func(t *testing.T) {
+		declineErr := fmt.Errorf("failed to create charge: %w", &stripego.Error{
+			Type:        stripego.ErrorTypeCard,
+			Code:        stripego.ErrorCodeCardDeclined,
+			DeclineCode: stripego.DeclineCodeInsufficientFunds,
+		})
+
+		assert.Equal(t, i18n.KeyInsufficientFunds, translator.Key(declineErr))
+		assert.Equal(t, "Tu tarjeta no tiene fondos suficientes.", translator.Localize(declineErr, "es"))
+	})
+
+	t.Run("should not reveal sensitive decline reasons", func(t *testing.T) {
+		stolenErr := &stripego.Error{
+			Type:        stripego.ErrorTypeCard,
+			Code:        stripego.ErrorCodeCardDeclined,
+			DeclineCode: stripego.DeclineCodeStolenCard,
+		}
+
+		assert.Equal(t, "Your card was declined.", translator.Localize(stolenErr, "en"))
+	})
+
+	t.Run("should map validation errors", func(t *testing.T) {
+		chargeService := stripe.NewChargeService()
+		_, err := chargeService.CreateCharge(context.Background(), &stripe.ChargeRequest{})
+
+		assert.Equal(t, i18n.KeyMissingParameter, translator.Key(err))
+	})
+
+	t.Run("should map registered errors", func(t *testing.T) {
+		errOnHold := errors.New("customer is on hold")
+		translator := i18n.NewTranslator()
+		translator.Register(errOnHold, i18n.KeyAccountOnHold)
+
+		assert.Equal(t, i18n.KeyAccountOnHold, translator.Key(fmt.Errorf("blocked: %w", errOnHold)))
+	})
+
+	t.Run("should fall back to a generic message for unknown errors", func(t *testing.T) {
+		msg := translator.Localize(errors.New("pq: connection refused"), "de")
+
+		assert.Equal(t, "Etwas ist schiefgelaufen. Bitte versuchen Sie es erneut.", msg)
+	})
+
+	t.Run("should have every message in every language", func(t *testing.T) {
+		english := translator.Message(i18n.KeyCardDeclined, "en")
+		for _, language := range translator.Languages() {
+			assert.NotEmpty(t, translator.Message(i18n.KeyCardDeclined, language))
+			if language != "en" {
+				assert.NotEqual(t, english, translator.Message(i18n.KeyCardDeclined, language))
+			}
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"apis/payments/services/instrumentation"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGatewayInstrumentation tests provider call measurement and latency percentiles
+func TestGatewayInstrumentation(t *testing.T) {
+	t.Run("should replace object IDs in operation names", func(t *testing.T) {
+		assert.Equal(t, "GET /v1/charges/:id", instrumentation.Operation("GET", "/v1/charges/ch_3MqL0a2eZvKYlo2C"))
+		assert.Equal(t, "POST /v1/customers/:id/sources", instrumentation.Operation("POST", "/v1/customers/cus_NffrFeUfNV2Hib/sources"))
+		assert.Equal(t, "GET /v1/payment_methods", instrumentation.Operation("GET", "/v1/payment_methods"))
+		assert.Equal(t, "POST /v1/payment_methods/:id/detach", instrumentation.Operation("POST", "/v1/payment_methods/pm_1Abc/detach"))
+	})
+
+	t.Run("should compute per-operation percentiles", func(t *testing.T) {
+		recorder := instrumentation.NewRecorder()
+		for i := 1; i <= 100; i++ {
+			recorder.Record(instrumentation.Call{
+				Provider:  "stripe",
+				Operation: "POST /v1/charges",
+				Duration:  time.Duration(i) * time.Millisecond,
+				Outcome:   instrumentation.OutcomeSuccess,
+			}, i > 95)
+		}
+		recorder.Record(instrumentation.Call{
+			Provider:  "stripe",
+			Operation: "GET /v1/charges/:id",
+			Duration:  time.Millisecond,
+			Outcome:   instrumentation.OutcomeClientError,
+		}, false)
+
+		stats := recorder.Snapshot()
+		require.Len(t, stats, 2)
+
+		charges := stats[0]
+		assert.Equal(t, "POST /v1/charges", charges.Operation)
+		assert.Equal(t, int64(100), charges.Count)
+		assert.Equal(t, int64(0), charges.Errors)
+		assert.Equal(t, int64(5), charges.Slow)
+		assert.Equal(t, 50.0, charges.P50Ms)
+		assert.Equal(t, 90.0, charges.P90Ms)
+		assert.Equal(t, 99.0, charges.P99Ms)
+		assert.Equal(t, 100.0, charges.MaxMs)
+
+		assert.Equal(t, int64(1), stats[1].Errors)
+	})
+
+	t.Run("should record calls made through the transport", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Request-Id", "req_123")
+			if r.URL.Path == "/v1/charges/ch_missing1" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		recorder := instrumentation.NewRecorder()
+		client := &http.Client{
+			Transport: instrumentation.NewTransport(nil, "stripe", recorder, time.Nanosecond),
+		}
+
+		resp, err := client.Post(server.URL+"/v1/charges", "application/x-www-form-urlencoded", nil)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		resp, err = client.Get(server.URL + "/v1/charges/ch_missing1")
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		stats := recorder.Snapshot()
+		require.Len(t, stats, 2)
+
+		byOperation := make(map[string]instrumentation.OperationStats)
+		for _, s := range stats {
+			byOperation[s.Operation] = s
+		}
+
+		assert.Equal(t, int64(1), byOperation["POST /v1/charges"].Count)
+		assert.Equal(t, int64(0), byOperation["POST /v1/charges"].Errors)
+		assert.Equal(t, int64(1), byOperation["POST /v1/charges"].Slow)
+		assert.Equal(t, int64(1), byOperation["GET /v1/charges/:id"].Errors)
+	})
+}
@@ -0,0 +1,418 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"apis/payments/services/events"
+	"apis/payments/services/invoicing"
+	"apis/payments/services/ledger"
+	"apis/payments/services/stripe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestInvoicing tests tracking NET terms invoices, reminders and offline payments
+func TestInvoicing(t *testing.T) {
+	ctx := context.Background()
+	dueDate := time.Date(2026, time.November, 15, 0, 0, 0, 0, time.UTC)
+	config := &invoicing.Config{ReminderOffsets: []int{-3, 1, 7}, Interval: time.Hour}
+
+	openInvoice := func() *stripe.Invoice {
+		due := dueDate
+		return &stripe.Invoice{
+			ID:               "in_1",
+			Number:           "INV-0001",
+			CustomerID:       "cus_1",
+			Status:           invoicing.StatusOpen,
+			CollectionMethod: "send_invoice",
+			AmountDue:        120000,
+			AmountRemaining:  120000,
+			Currency:         "usd",
+			DueDate:          &due,
+		}
+	}
+
+	setup := func() (*invoicing.Service, *MockReceivableStore, *MockInvoiceProvider, *MockLedgerStore, *MockEventPublisher) {
+		store := NewMockReceivableStore()
+		provider := &MockInvoiceProvider{remote: make(map[string]*stripe.Invoice)}
+		ledgerStore := NewMockLedgerStore()
+		publisher := &MockEventPublisher{}
+		source, _ := events.NewSource("/payments")
+		service := invoicing.NewService(store, provider, ledger.NewService(ledgerStore), events.NewEmitter(source, publisher), config)
+		return service, store, provider, ledgerStore, publisher
+	}
+
+	t.Run("should convert payment terms to days until due", func(t *testing.T) {
+		days, err := stripe.PaymentTermDays(stripe.TermsNet30)
+		require.NoError(t, err)
+		assert.Equal(t, int64(30), days)
+
+		_, err = stripe.PaymentTermDays("net_45")
+		assert.ErrorIs(t, err, stripe.ErrInvalidPaymentTerms)
+	})
+
+	t.Run("should track finalized invoices as receivables", func(t *testing.T) {
+		service, store, _, ledgerStore, _ := setup()
+
+		require.NoError(t, service.Track(ctx, openInvoice()))
+
+		assert.Equal(t, invoicing.StatusOpen, store.invoices["in_1"].Status)
+		require.Len(t, ledgerStore.entries, 1)
+		assert.Equal(t, ledger.AccountReceivable, ledgerStore.entries[0].DebitAccount)
+		assert.Equal(t, ledger.AccountRevenue, ledgerStore.entries[0].CreditAccount)
+	})
+
+	t.Run("should ignore invoices charged automatically", func(t *testing.T) {
+		service, store, _, _, _ := setup()
+		inv := openInvoice()
+		inv.CollectionMethod = "charge_automatically"
+
+		require.NoError(t, service.Track(ctx, inv))
+		assert.Empty(t, store.invoices)
+	})
+
+	t.Run("should send reminders at configured offsets", func(t *testing.T) {
+		service, _, _, _, publisher := setup()
+		require.NoError(t, service.Track(ctx, openInvoice()))
+
+		sent, err := service.SendReminders(ctx, dueDate.AddDate(0, 0, -5))
+		require.NoError(t, err)
+		assert.Equal(t, 0, sent)
+
+		sent, err = service.SendReminders(ctx, dueDate.AddDate(0, 0, -2))
+		require.NoError(t, err)
+		assert.Equal(t, 1, sent)
+
+		// Running again the same day sends nothing new
+		sent, err = service.SendReminders(ctx, dueDate.AddDate(0, 0, -2))
+		require.NoError(t, err)
+		assert.Equal(t, 0, sent)
+
+		require.Len(t, publisher.events, 1)
+		var reminder invoicing.Reminder
+		require.NoError(t, json.Unmarshal(publisher.events[0].Data, &reminder))
+		assert.Equal(t, -3, reminder.DaysFromDue)
+	})
+
+	t.Run("should flag overdue invoices once and send only the latest missed reminder", func(t *testing.T) {
+		service, store, _, _, publisher := setup()
+		require.NoError(t, service.Track(ctx, openInvoice()))
+
+		sent, err := service.SendReminders(ctx, dueDate.AddDate(0, 0, 8))
+		require.NoError(t, err)
+		assert.Equal(t, 1, sent)
+		assert.NotNil(t, store.invoices["in_1"].OverdueAt)
+		assert.ElementsMatch(t, []int{-3, 1, 7}, store.reminders["in_1"])
+
+		require.Len(t, publisher.events, 2)
+		assert.Equal(t, invoicing.EventInvoiceOverdue, publisher.events[0].Type)
+		assert.Equal(t, invoicing.EventInvoiceReminder, publisher.events[1].Type)
+		var reminder invoicing.Reminder
+		require.NoError(t, json.Unmarshal(publisher.events[1].Data, &reminder))
+		assert.Equal(t, 7, reminder.DaysFromDue)
+
+		_, err = service.SendReminders(ctx, dueDate.AddDate(0, 0, 9))
+		require.NoError(t, err)
+		assert.Len(t, publisher.events, 2)
+	})
+
+	t.Run("should mark invoices paid by bank transfer", func(t *testing.T) {
+		service, store, provider, ledgerStore, _ := setup()
+		require.NoError(t, service.Track(ctx, openInvoice()))
+
+		paid, err := service.MarkPaid(ctx, "in_1", &invoicing.MarkPaidRequest{Reference: "TRF-118"})
+		require.NoError(t, err)
+		assert.Equal(t, invoicing.StatusPaid, paid.Status)
+		assert.Equal(t, "TRF-118", paid.PaidReference)
+		assert.Equal(t, []string{"in_1"}, provider.paidOutOfBand)
+
+		require.Len(t, ledgerStore.entries, 2)
+		assert.Equal(t, ledger.AccountBank, ledgerStore.entries[1].DebitAccount)
+		assert.Equal(t, ledger.AccountReceivable, ledgerStore.entries[1].CreditAccount)
+
+		// The paid webhook for the same payment doesn't post again
+		inv := openInvoice()
+		inv.Status = invoicing.StatusPaid
+		inv.PaidOutOfBand = true
+		require.NoError(t, service.Track(ctx, inv))
+		assert.Len(t, ledgerStore.entries, 2)
+		assert.Equal(t, "TRF-118", store.invoices["in_1"].PaidReference)
+	})
+
+	t.Run("should not mark settled invoices paid", func(t *testing.T) {
+		service, _, _, _, _ := setup()
+		inv := openInvoice()
+		inv.Status = invoicing.StatusVoid
+		require.NoError(t, service.Track(ctx, inv))
+
+		_, err := service.MarkPaid(ctx, "in_1", &invoicing.MarkPaidRequest{Reference: "TRF-118"})
+		assert.ErrorIs(t, err, invoicing.ErrInvoiceNotOpen)
+	})
+
+	t.Run("should require a transfer reference", func(t *testing.T) {
+		service, _, _, _, _ := setup()
+		require.NoError(t, service.Track(ctx, openInvoice()))
+
+		_, err := service.MarkPaid(ctx, "in_1", &invoicing.MarkPaidRequest{})
+		assert.Error(t, err)
+	})
+
+	t.Run("should store invoices through their lifecycle", func(t *testing.T) {
+		service, store, _, _, _ := setup()
+
+		created, err := service.Create(ctx, &stripe.CreateInvoiceRequest{
+			CustomerID: "cus_1",
+			Currency:   "usd",
+			Items:      []stripe.InvoiceItemRequest{{Amount: 5000, Description: "Setup fee"}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "draft", store.synced[created.ID].Status)
+		assert.Equal(t, int64(5000), store.synced[created.ID].AmountDue)
+
+		_, err = service.Finalize(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, invoicing.StatusOpen, store.synced[created.ID].Status)
+
+		paid, err := service.Pay(ctx, created.ID, "pm_1")
+		require.NoError(t, err)
+		assert.Equal(t, invoicing.StatusPaid, paid.Status)
+		assert.Equal(t, invoicing.StatusPaid, store.synced[created.ID].Status)
+
+		listed, err := service.List(ctx, invoicing.InvoiceFilter{CustomerID: "cus_1"})
+		require.NoError(t, err)
+		require.Len(t, listed, 1)
+		assert.Equal(t, 50, store.lastFilter.Limit)
+	})
+
+	t.Run("should void open invoices", func(t *testing.T) {
+		service, store, provider, _, _ := setup()
+		provider.remote["in_1"] = openInvoice()
+
+		_, err := service.Void(ctx, "in_1")
+		require.NoError(t, err)
+		assert.Equal(t, invoicing.StatusVoid, store.synced["in_1"].Status)
+	})
+
+	t.Run("should fetch invoices not yet synced from the provider", func(t *testing.T) {
+		service, store, provider, _, _ := setup()
+		provider.remote["in_1"] = openInvoice()
+
+		inv, err := service.Get(ctx, "in_1")
+		require.NoError(t, err)
+		assert.Equal(t, "INV-0001", inv.Number)
+		assert.Contains(t, store.synced, "in_1")
+
+		_, err = service.Get(ctx, "in_missing")
+		assert.Error(t, err)
+	})
+
+	t.Run("should only return PDF URLs of finalized invoices", func(t *testing.T) {
+		service, _, provider, _, _ := setup()
+		draft := &stripe.Invoice{ID: "in_2", CustomerID: "cus_1", Status: "draft", Currency: "usd"}
+		provider.remote["in_2"] = draft
+
+		_, err := service.PDFURL(ctx, "in_2")
+		assert.ErrorIs(t, err, invoicing.ErrPDFUnavailable)
+
+		// Finalized at the provider since it was last synced
+		finalized := *draft
+		finalized.Status = invoicing.StatusOpen
+		finalized.InvoicePDF = "https://pay.stripe.com/invoice/in_2/pdf"
+		provider.remote["in_2"] = &finalized
+
+		url, err := service.PDFURL(ctx, "in_2")
+		require.NoError(t, err)
+		assert.Equal(t, finalized.InvoicePDF, url)
+	})
+}
+
+// MockReceivableStore is an in-memory invoicing.Store
+type MockReceivableStore struct {
+	invoices   map[string]*invoicing.Invoice
+	reminders  map[string][]int
+	synced     map[string]*stripe.Invoice
+	lastFilter invoicing.InvoiceFilter
+}
+
+func NewMockReceivableStore() *MockReceivableStore {
+	return &MockReceivableStore{
+		invoices:  make(map[string]*invoicing.Invoice),
+		reminders: make(map[string][]int),
+		synced:    make(map[string]*stripe.Invoice),
+	}
+}
+
+func (m *MockReceivableStore) UpsertInvoice(ctx context.Context, inv *stripe.Invoice, syncedAt time.Time) error {
+	copied := *inv
+	m.synced[inv.ID] = &copied
+	return nil
+}
+
+func (m *MockReceivableStore) GetInvoice(ctx context.Context, invoiceID string) (*stripe.Invoice, error) {
+	inv, ok := m.synced[invoiceID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *inv
+	return &copied, nil
+}
+
+func (m *MockReceivableStore) ListInvoices(ctx context.Context, filter invoicing.InvoiceFilter) ([]*stripe.Invoice, error) {
+	m.lastFilter = filter
+	var invoices []*stripe.Invoice
+	for _, inv := range m.synced {
+		if (filter.CustomerID == "" || inv.CustomerID == filter.CustomerID) && (filter.Status == "" || inv.Status == filter.Status) {
+			invoices = append(invoices, inv)
+		}
+	}
+	return invoices, nil
+}
+
+func (m *MockReceivableStore) DeleteInvoice(ctx context.Context, invoiceID string) error {
+	delete(m.synced, invoiceID)
+	return nil
+}
+
+func (m *MockReceivableStore) GetReceivableInvoice(ctx context.Context, invoiceID string) (*invoicing.Invoice, error) {
+	inv, ok := m.invoices[invoiceID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *inv
+	return &copied, nil
+}
+
+func (m *MockReceivableStore) UpsertReceivableInvoice(ctx context.Context, inv *invoicing.Invoice) (*invoicing.Invoice, error) {
+	if existing, ok := m.invoices[inv.InvoiceID]; ok {
+		existing.Number = inv.Number
+		existing.AmountDue = inv.AmountDue
+		existing.AmountRemaining = inv.AmountRemaining
+		existing.DueDate = inv.DueDate
+		existing.Status = inv.Status
+		copied := *existing
+		return &copied, nil
+	}
+	stored := *inv
+	m.invoices[inv.InvoiceID] = &stored
+	copied := stored
+	return &copied, nil
+}
+
+func (m *MockReceivableStore) ListOpenReceivableInvoices(ctx context.Context) ([]*invoicing.Invoice, error) {
+	var invoices []*invoicing.Invoice
+	for _, inv := range m.invoices {
+		if inv.Status == invoicing.StatusOpen {
+			copied := *inv
+			invoices = append(invoices, &copied)
+		}
+	}
+	return invoices, nil
+}
+
+func (m *MockReceivableStore) ListOverdueReceivableInvoices(ctx context.Context, customerID string, now time.Time) ([]*invoicing.Invoice, error) {
+	var invoices []*invoicing.Invoice
+	for _, inv := range m.invoices {
+		if inv.Status == invoicing.StatusOpen && inv.DueDate.Before(now) && (customerID == "" || inv.CustomerID == customerID) {
+			invoices = append(invoices, inv)
+		}
+	}
+	return invoices, nil
+}
+
+func (m *MockReceivableStore) MarkReceivableInvoiceOverdue(ctx context.Context, invoiceID string, at time.Time) error {
+	if inv, ok := m.invoices[invoiceID]; ok && inv.OverdueAt == nil {
+		inv.OverdueAt = &at
+	}
+	return nil
+}
+
+func (m *MockReceivableStore) MarkReceivableInvoicePaid(ctx context.Context, invoiceID, reference string, at time.Time) (*invoicing.Invoice, error) {
+	inv, ok := m.invoices[invoiceID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	inv.Status = invoicing.StatusPaid
+	inv.AmountRemaining = 0
+	inv.PaidAt = &at
+	inv.PaidReference = reference
+	copied := *inv
+	return &copied, nil
+}
+
+func (m *MockReceivableStore) ListInvoiceReminderOffsets(ctx context.Context, invoiceID string) ([]int, error) {
+	return m.reminders[invoiceID], nil
+}
+
+func (m *MockReceivableStore) RecordInvoiceReminder(ctx context.Context, invoiceID string, offsetDays int) error {
+	for _, offset := range m.reminders[invoiceID] {
+		if offset == offsetDays {
+			return nil
+		}
+	}
+	m.reminders[invoiceID] = append(m.reminders[invoiceID], offsetDays)
+	return nil
+}
+
+// MockInvoiceProvider is an in-memory invoicing.InvoiceProvider that records
+// invoices marked paid out of band
+type MockInvoiceProvider struct {
+	remote        map[string]*stripe.Invoice
+	paidOutOfBand []string
+}
+
+func (m *MockInvoiceProvider) CreateInvoice(ctx context.Context, request *stripe.CreateInvoiceRequest) (*stripe.Invoice, error) {
+	inv := &stripe.Invoice{
+		ID:         fmt.Sprintf("in_%d", len(m.remote)+1),
+		CustomerID: request.CustomerID,
+		Status:     "draft",
+		Currency:   request.Currency,
+	}
+	for _, item := range request.Items {
+		inv.AmountDue += item.Amount
+	}
+	m.remote[inv.ID] = inv
+	copied := *inv
+	return &copied, nil
+}
+
+func (m *MockInvoiceProvider) GetInvoice(ctx context.Context, invoiceID string) (*stripe.Invoice, error) {
+	inv, ok := m.remote[invoiceID]
+	if !ok {
+		return nil, fmt.Errorf("no such invoice: %s", invoiceID)
+	}
+	copied := *inv
+	return &copied, nil
+}
+
+func (m *MockInvoiceProvider) FinalizeInvoice(ctx context.Context, invoiceID string) (*stripe.Invoice, error) {
+	return m.transition(invoiceID, invoicing.StatusOpen)
+}
+
+func (m *MockInvoiceProvider) PayInvoice(ctx context.Context, invoiceID, paymentMethodID string) (*stripe.Invoice, error) {
+	return m.transition(invoiceID, invoicing.StatusPaid)
+}
+
+func (m *MockInvoiceProvider) VoidInvoice(ctx context.Context, invoiceID string) (*stripe.Invoice, error) {
+	return m.transition(invoiceID, invoicing.StatusVoid)
+}
+
+func (m *MockInvoiceProvider) transition(invoiceID, status string) (*stripe.Invoice, error) {
+	inv, ok := m.remote[invoiceID]
+	if !ok {
+		return nil, fmt.Errorf("no such invoice: %s", invoiceID)
+	}
+	inv.Status = status
+	copied := *inv
+	return &copied, nil
+}
+
+func (m *MockInvoiceProvider) MarkInvoicePaidOutOfBand(ctx context.Context, invoiceID string) (*stripe.Invoice, error) {
+	m.paidOutOfBand = append(m.paidOutOfBand, invoiceID)
+	return &stripe.Invoice{ID: invoiceID, Status: invoicing.StatusPaid, PaidOutOfBand: true}, nil
+}
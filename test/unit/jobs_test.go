@@ -0,0 +1,226 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"apis/payments/services/jobs"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestJobs tests parsing job schedules and running jobs once across
+// instances with retries and a recorded history
+func TestJobs(t *testing.T) {
+	ctx := context.Background()
+	at := func(value string) time.Time {
+		parsed, err := time.Parse(time.RFC3339, value)
+		require.NoError(t, err)
+		return parsed
+	}
+
+	t.Run("should find the next run of cron schedules", func(t *testing.T) {
+		cases := []struct {
+			spec, after, next string
+		}{
+			{"*/15 * * * *", "2026-03-10T10:07:30Z", "2026-03-10T10:15:00Z"},
+			{"0 2 * * *", "2026-03-10T02:00:00Z", "2026-03-11T02:00:00Z"},
+			{"@hourly", "2026-03-10T23:59:00Z", "2026-03-11T00:00:00Z"},
+			{"30 9 * * 1-5", "2026-03-13T10:00:00Z", "2026-03-16T09:30:00Z"},
+			{"0 0 1,15 * *", "2026-02-16T00:00:00Z", "2026-03-01T00:00:00Z"},
+			{"0 0 29 2 *", "2026-03-01T00:00:00Z", "2028-02-29T00:00:00Z"},
+			{"0 12 13 * 5", "2026-03-10T00:00:00Z", "2026-03-13T12:00:00Z"},
+			{"0 0 * * 7", "2026-03-10T00:00:00Z", "2026-03-15T00:00:00Z"},
+			{"@every 90s", "2026-03-10T10:00:00Z", "2026-03-10T10:01:30Z"},
+		}
+		for _, c := range cases {
+			schedule, err := jobs.ParseSchedule(c.spec)
+			require.NoError(t, err, c.spec)
+			assert.Equal(t, at(c.next), schedule.Next(at(c.after)), c.spec)
+		}
+
+		for _, spec := range []string{"* * * *", "60 * * * *", "*/0 * * * *", "5-1 * * * *", "@every 1ms", "@yearly"} {
+			_, err := jobs.ParseSchedule(spec)
+			assert.ErrorIs(t, err, jobs.ErrInvalidSchedule, spec)
+		}
+	})
+
+	setup := func() (*jobs.Scheduler, *MockJobStore, *MockJobLocker) {
+		store := &MockJobStore{}
+		locker := &MockJobLocker{held: map[string]bool{}}
+		scheduler := jobs.NewScheduler(store, locker, &jobs.Config{
+			MaxAttempts:  3,
+			RetryBackoff: time.Millisecond,
+			Schedules:    map[string]string{"nightly": "0 4 * * *"},
+		})
+		return scheduler, store, locker
+	}
+
+	t.Run("should retry failing runs and record their outcome", func(t *testing.T) {
+		scheduler, store, locker := setup()
+		calls := 0
+		require.NoError(t, scheduler.Register(&jobs.Job{
+			Name:     "flaky",
+			Schedule: "@hourly",
+			Run: func(ctx context.Context) error {
+				calls++
+				if calls < 2 {
+					return errors.New("provider unavailable")
+				}
+				return nil
+			},
+		}))
+
+		run, err := scheduler.RunNow(ctx, "flaky")
+		require.NoError(t, err)
+		assert.Equal(t, jobs.TriggerManual, run.TriggeredBy)
+		finished := store.waitFinished(t, run.ID)
+		assert.Equal(t, jobs.StatusSucceeded, finished.Status)
+		assert.Equal(t, 2, finished.Attempts)
+		assert.Equal(t, []string{"flaky"}, store.abandoned, "runs left running elsewhere are abandoned")
+		assert.Eventually(t, func() bool { return !locker.locked("flaky") }, time.Second, time.Millisecond, "the lock is released")
+
+		require.NoError(t, scheduler.Register(&jobs.Job{
+			Name:        "broken",
+			Schedule:    "@hourly",
+			MaxAttempts: 2,
+			Run:         func(ctx context.Context) error { return errors.New("bad data") },
+		}))
+		run, err = scheduler.RunNow(ctx, "broken")
+		require.NoError(t, err)
+		finished = store.waitFinished(t, run.ID)
+		assert.Equal(t, jobs.StatusFailed, finished.Status)
+		assert.Equal(t, 2, finished.Attempts)
+		assert.Equal(t, "bad data", finished.Error)
+	})
+
+	t.Run("should not run jobs another instance is running", func(t *testing.T) {
+		scheduler, store, locker := setup()
+		require.NoError(t, scheduler.Register(&jobs.Job{
+			Name:     "sweep",
+			Schedule: "@every 1h",
+			Run:      func(ctx context.Context) error { return nil },
+		}))
+		locker.held["sweep"] = true
+
+		_, err := scheduler.RunNow(ctx, "sweep")
+		assert.ErrorIs(t, err, jobs.ErrJobRunning)
+		assert.Empty(t, store.created)
+
+		_, err = scheduler.RunNow(ctx, "missing")
+		assert.ErrorIs(t, err, jobs.ErrJobNotFound)
+	})
+
+	t.Run("should list jobs with their schedule and last run", func(t *testing.T) {
+		scheduler, store, _ := setup()
+		require.NoError(t, scheduler.Register(nil), "disabled jobs are skipped")
+		require.NoError(t, scheduler.Register(&jobs.Job{Name: "nightly", Schedule: "0 2 * * *", Run: func(ctx context.Context) error { return nil }}))
+		assert.ErrorIs(t, scheduler.Register(&jobs.Job{Name: "bad", Schedule: "daily"}), jobs.ErrInvalidSchedule)
+
+		run, err := scheduler.RunNow(ctx, "nightly")
+		require.NoError(t, err)
+		store.waitFinished(t, run.ID)
+
+		statuses, err := scheduler.Jobs(ctx)
+		require.NoError(t, err)
+		require.Len(t, statuses, 1)
+		assert.Equal(t, "0 4 * * *", statuses[0].Schedule, "configured schedules override the job's")
+		require.NotNil(t, statuses[0].NextRunAt)
+		assert.Equal(t, 4, statuses[0].NextRunAt.Hour())
+		require.NotNil(t, statuses[0].LastRun)
+		assert.Equal(t, run.ID, statuses[0].LastRun.ID)
+
+		runs, err := scheduler.Runs(ctx, "nightly", 0)
+		require.NoError(t, err)
+		assert.Len(t, runs, 1)
+		assert.Len(t, store.created, 1)
+	})
+}
+
+// MockJobStore keeps job runs in memory
+type MockJobStore struct {
+	mu        sync.Mutex
+	created   []*jobs.Run
+	finished  map[string]*jobs.Run
+	abandoned []string
+}
+
+func (m *MockJobStore) CreateJobRun(ctx context.Context, run *jobs.Run) (*jobs.Run, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.created = append(m.created, run)
+	return run, nil
+}
+
+func (m *MockJobStore) FinishJobRun(ctx context.Context, run *jobs.Run) (*jobs.Run, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.finished == nil {
+		m.finished = map[string]*jobs.Run{}
+	}
+	finished := *run
+	m.finished[run.ID] = &finished
+	return &finished, nil
+}
+
+// waitFinished waits for a run to be finished
+func (m *MockJobStore) waitFinished(t *testing.T, runID string) *jobs.Run {
+	var finished *jobs.Run
+	require.Eventually(t, func() bool {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		finished = m.finished[runID]
+		return finished != nil
+	}, time.Second, time.Millisecond)
+	return finished
+}
+
+func (m *MockJobStore) AbandonJobRuns(ctx context.Context, job string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.abandoned = append(m.abandoned, job)
+	return nil
+}
+
+func (m *MockJobStore) ListJobRuns(ctx context.Context, job string, limit int) ([]*jobs.Run, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	runs := []*jobs.Run{}
+	for i := len(m.created) - 1; i >= 0 && len(runs) < limit; i-- {
+		if m.created[i].Job == job {
+			runs = append(runs, m.created[i])
+		}
+	}
+	return runs, nil
+}
+
+// MockJobLocker holds job locks in memory, as other instances would
+type MockJobLocker struct {
+	mu   sync.Mutex
+	held map[string]bool
+}
+
+func (m *MockJobLocker) TryJobLock(ctx context.Context, job string) (func(), bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.held[job] {
+		return nil, false, nil
+	}
+	m.held[job] = true
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.held[job] = false
+	}, true, nil
+}
+
+// locked reports whether a job's lock is held
+func (m *MockJobLocker) locked(job string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.held[job]
+}
@@ -0,0 +1,177 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"apis/payments/services/ledger"
+	"apis/payments/services/stripe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// TestLedger tests posting provider events as balanced entries and the
+// trial balance over them
+func TestLedger(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func() (*ledger.Service, *stripe.WebhookService, *MockLedgerStore) {
+		store := NewMockLedgerStore()
+		service := ledger.NewService(store)
+		webhooks := stripe.NewWebhookService("whsec_test")
+		service.RegisterWebhookHandlers(webhooks, &MockBalanceTransactions{fees: map[string]int64{"txn_1": 59}})
+		return service, webhooks, store
+	}
+
+	dispatch := func(t *testing.T, webhooks *stripe.WebhookService, eventType stripego.EventType, raw string) {
+		require.NoError(t, webhooks.Dispatch(ctx, stripego.Event{
+			ID:   "evt_1",
+			Type: eventType,
+			Data: &stripego.EventData{Raw: []byte(raw)},
+		}))
+	}
+
+	balanceOf := func(t *testing.T, service *ledger.Service, account string) int64 {
+		balances, err := service.TrialBalance(ctx, time.Now())
+		require.NoError(t, err)
+		for _, balance := range balances {
+			for _, accountBalance := range balance.Accounts {
+				if accountBalance.Account == account && balance.Currency == "usd" {
+					return accountBalance.Balance
+				}
+			}
+		}
+		return 0
+	}
+
+	t.Run("should post captured charges with their processing fee", func(t *testing.T) {
+		service, webhooks, store := setup()
+
+		dispatch(t, webhooks, stripego.EventTypeChargeSucceeded,
+			`{"id": "ch_1", "captured": false, "amount": 2000, "amount_captured": 0, "currency": "usd"}`)
+		assert.Empty(t, store.entries)
+
+		raw := `{"id": "ch_1", "captured": true, "amount": 2000, "amount_captured": 2000, "currency": "usd", "balance_transaction": "txn_1"}`
+		dispatch(t, webhooks, stripego.EventTypeChargeCaptured, raw)
+		dispatch(t, webhooks, stripego.EventTypeChargeSucceeded, raw)
+
+		require.Len(t, store.entries, 2)
+		assert.Equal(t, int64(2000), balanceOf(t, service, ledger.AccountRevenue))
+		assert.Equal(t, int64(59), balanceOf(t, service, ledger.AccountProviderFees))
+		assert.Equal(t, int64(1941), balanceOf(t, service, ledger.AccountMerchantReceivable))
+	})
+
+	t.Run("should post refunds once they succeed and reverse failed ones", func(t *testing.T) {
+		service, webhooks, store := setup()
+
+		dispatch(t, webhooks, stripego.EventTypeRefundCreated, `{"id": "re_1", "amount": 500, "currency": "usd", "status": "pending"}`)
+		assert.Empty(t, store.entries)
+
+		dispatch(t, webhooks, stripego.EventTypeRefundUpdated, `{"id": "re_1", "amount": 500, "currency": "usd", "status": "succeeded"}`)
+		assert.Equal(t, int64(500), balanceOf(t, service, ledger.AccountRefunds))
+
+		dispatch(t, webhooks, stripego.EventTypeRefundUpdated, `{"id": "re_1", "amount": 500, "currency": "usd", "status": "failed"}`)
+		assert.Len(t, store.entries, 2)
+		assert.Equal(t, int64(0), balanceOf(t, service, ledger.AccountRefunds))
+	})
+
+	t.Run("should hold disputed funds in reserve until the dispute closes", func(t *testing.T) {
+		service, webhooks, _ := setup()
+
+		dispatch(t, webhooks, stripego.EventTypeChargeDisputeCreated,
+			`{"id": "dp_1", "amount": 2000, "currency": "usd", "status": "needs_response",
+			  "balance_transactions": [{"id": "txn_2", "amount": -2000, "fee": 1500, "currency": "usd"}]}`)
+		assert.Equal(t, int64(2000), balanceOf(t, service, ledger.AccountReserve))
+		assert.Equal(t, int64(1500), balanceOf(t, service, ledger.AccountProviderFees))
+
+		dispatch(t, webhooks, stripego.EventTypeChargeDisputeClosed,
+			`{"id": "dp_1", "amount": 2000, "currency": "usd", "status": "lost",
+			  "balance_transactions": [{"id": "txn_2", "amount": -2000, "fee": 1500, "currency": "usd"}]}`)
+		assert.Equal(t, int64(0), balanceOf(t, service, ledger.AccountReserve))
+		assert.Equal(t, int64(2000), balanceOf(t, service, ledger.AccountChargebacks))
+		assert.Equal(t, int64(1500), balanceOf(t, service, ledger.AccountProviderFees))
+	})
+
+	t.Run("should not post inquiries that withdraw no funds", func(t *testing.T) {
+		_, webhooks, store := setup()
+
+		dispatch(t, webhooks, stripego.EventTypeChargeDisputeCreated,
+			`{"id": "dp_2", "amount": 2000, "currency": "usd", "status": "warning_needs_response", "balance_transactions": []}`)
+		assert.Empty(t, store.entries)
+	})
+
+	t.Run("should post payouts and reverse returned ones", func(t *testing.T) {
+		service, webhooks, _ := setup()
+
+		dispatch(t, webhooks, stripego.EventTypePayoutPaid, `{"id": "po_1", "amount": 1000, "currency": "usd", "status": "paid"}`)
+		assert.Equal(t, int64(1000), balanceOf(t, service, ledger.AccountBank))
+
+		dispatch(t, webhooks, stripego.EventTypePayoutFailed, `{"id": "po_1", "amount": 1000, "currency": "usd", "status": "failed"}`)
+		assert.Equal(t, int64(0), balanceOf(t, service, ledger.AccountBank))
+	})
+
+	t.Run("should ignore connected account events", func(t *testing.T) {
+		_, webhooks, store := setup()
+
+		require.NoError(t, webhooks.Dispatch(ctx, stripego.Event{
+			ID:      "evt_2",
+			Type:    stripego.EventTypePayoutPaid,
+			Account: "acct_1",
+			Data:    &stripego.EventData{Raw: []byte(`{"id": "po_2", "amount": 1000, "currency": "usd", "status": "paid"}`)},
+		}))
+		assert.Empty(t, store.entries)
+	})
+
+	t.Run("should produce a balanced trial balance per currency", func(t *testing.T) {
+		service, webhooks, _ := setup()
+
+		dispatch(t, webhooks, stripego.EventTypeChargeCaptured,
+			`{"id": "ch_1", "captured": true, "amount_captured": 2000, "currency": "usd", "balance_transaction": "txn_1"}`)
+		dispatch(t, webhooks, stripego.EventTypeChargeCaptured,
+			`{"id": "ch_2", "captured": true, "amount_captured": 1500, "currency": "eur"}`)
+		dispatch(t, webhooks, stripego.EventTypePayoutPaid, `{"id": "po_1", "amount": 1000, "currency": "usd", "status": "paid"}`)
+
+		balances, err := service.TrialBalance(ctx, time.Now())
+		require.NoError(t, err)
+		require.Len(t, balances, 2)
+		assert.Equal(t, "eur", balances[0].Currency)
+		assert.Equal(t, "usd", balances[1].Currency)
+		for _, balance := range balances {
+			assert.True(t, balance.Balanced)
+			assert.Equal(t, balance.TotalDebits, balance.TotalCredits)
+		}
+		assert.Equal(t, int64(941), balanceOf(t, service, ledger.AccountMerchantReceivable))
+	})
+
+	t.Run("should list account entries and reject unknown accounts", func(t *testing.T) {
+		service, webhooks, _ := setup()
+
+		dispatch(t, webhooks, stripego.EventTypeChargeCaptured,
+			`{"id": "ch_1", "captured": true, "amount_captured": 2000, "currency": "usd", "balance_transaction": "txn_1"}`)
+		dispatch(t, webhooks, stripego.EventTypePayoutPaid, `{"id": "po_1", "amount": 1000, "currency": "usd", "status": "paid"}`)
+
+		entries, err := service.AccountEntries(ctx, ledger.AccountMerchantReceivable, "USD", 0, 0)
+		require.NoError(t, err)
+		require.Len(t, entries, 3)
+		assert.Equal(t, ledger.ReferencePayout, entries[0].ReferenceType)
+
+		entries, err = service.AccountEntries(ctx, ledger.AccountMerchantReceivable, "eur", 0, 0)
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+
+		_, err = service.AccountEntries(ctx, "petty_cash", "", 0, 0)
+		assert.ErrorIs(t, err, ledger.ErrUnknownAccount)
+	})
+}
+
+// MockBalanceTransactions returns balance transactions with fixed fees
+type MockBalanceTransactions struct {
+	fees map[string]int64
+}
+
+func (m *MockBalanceTransactions) GetBalanceTransaction(ctx context.Context, transactionID string) (*stripe.BalanceTransaction, error) {
+	return &stripe.BalanceTransaction{ID: transactionID, Fee: m.fees[transactionID], Currency: "usd"}, nil
+}
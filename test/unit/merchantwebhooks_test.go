@@ -0,0 +1,439 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"apis/payments/services/events"
+	"apis/payments/services/merchantwebhooks"
+	"apis/payments/services/tenancy"
+	"apis/payments/services/tenantcredentials"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMerchantWebhooks tests registering webhook endpoints and delivering
+// signed events to them with retries
+func TestMerchantWebhooks(t *testing.T) {
+	ctx := tenancy.WithTenant(context.Background(), "tenant_1")
+	setup := func(config *merchantwebhooks.Config) (*merchantwebhooks.Service, *MockMerchantWebhookStore) {
+		cipher, err := tenantcredentials.NewCipher(make([]byte, 32))
+		require.NoError(t, err)
+		if config == nil {
+			config = &merchantwebhooks.Config{Enabled: true, MaxAttempts: 3, Backoff: time.Minute, MaxBackoff: time.Hour, Timeout: time.Second, BatchSize: 10, AllowHTTP: true, AllowPrivateNetworks: true}
+		}
+		store := NewMockMerchantWebhookStore()
+		return merchantwebhooks.NewService(store, cipher, config), store
+	}
+	event := func(eventType string) *events.Event {
+		return &events.Event{SpecVersion: events.SpecVersion, ID: "evt_" + eventType, Type: eventType, Time: time.Now().UTC(), Data: json.RawMessage(`{"id":"ch_1"}`)}
+	}
+
+	t.Run("should deliver subscribed events signed with the endpoint secret", func(t *testing.T) {
+		service, store := setup(nil)
+
+		var mu sync.Mutex
+		var received []*http.Request
+		var bodies [][]byte
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			mu.Lock()
+			received = append(received, r)
+			bodies = append(bodies, body)
+			mu.Unlock()
+		}))
+		defer server.Close()
+
+		endpoint, err := service.CreateEndpoint(ctx, "tenant_1", merchantwebhooks.CreateEndpointRequest{URL: server.URL, EventTypes: []string{"payments.charge.*"}})
+		require.NoError(t, err)
+		assert.NotEmpty(t, endpoint.Secret)
+
+		require.NoError(t, service.Publish(ctx, event("payments.charge.succeeded")))
+		require.NoError(t, service.Publish(ctx, event("payments.refund.created")))
+		// Events of other tenants are not sent to this tenant's endpoints
+		require.NoError(t, service.Publish(tenancy.WithTenant(context.Background(), "tenant_2"), event("payments.charge.failed")))
+
+		result, err := service.DeliverDue(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Attempted)
+		assert.Equal(t, 1, result.Succeeded)
+
+		require.Len(t, received, 1)
+		assert.Equal(t, "payments.charge.succeeded", received[0].Header.Get(merchantwebhooks.EventTypeHeader))
+		assert.NoError(t, merchantwebhooks.Verify(endpoint.Secret, received[0].Header.Get(merchantwebhooks.SignatureHeader), bodies[0], time.Minute))
+		assert.ErrorIs(t, merchantwebhooks.Verify("whsec_other", received[0].Header.Get(merchantwebhooks.SignatureHeader), bodies[0], time.Minute), merchantwebhooks.ErrInvalidSignature)
+
+		var delivered events.Event
+		require.NoError(t, json.Unmarshal(bodies[0], &delivered))
+		assert.Equal(t, "evt_payments.charge.succeeded", delivered.ID)
+
+		deliveries, err := service.ListDeliveries(ctx, "tenant_1", endpoint.ID, merchantwebhooks.StatusSucceeded, 0)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		assert.NotNil(t, deliveries[0].DeliveredAt)
+		assert.Len(t, store.attempts, 1)
+	})
+
+	t.Run("should queue an event once per endpoint", func(t *testing.T) {
+		service, store := setup(nil)
+
+		_, err := service.CreateEndpoint(ctx, "tenant_1", merchantwebhooks.CreateEndpointRequest{URL: "https://example.com/hooks"})
+		require.NoError(t, err)
+
+		require.NoError(t, service.Publish(ctx, event("payments.charge.succeeded")))
+		require.NoError(t, service.Publish(ctx, event("payments.charge.succeeded")))
+		assert.Len(t, store.deliveries, 1)
+	})
+
+	t.Run("should retry failed deliveries with backoff until attempts run out", func(t *testing.T) {
+		service, store := setup(nil)
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte("boom"))
+		}))
+		defer server.Close()
+
+		endpoint, err := service.CreateEndpoint(ctx, "tenant_1", merchantwebhooks.CreateEndpointRequest{URL: server.URL})
+		require.NoError(t, err)
+		require.NoError(t, service.Publish(ctx, event("payments.charge.succeeded")))
+
+		result, err := service.DeliverDue(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Retrying)
+
+		deliveries, err := service.ListDeliveries(ctx, "tenant_1", endpoint.ID, "", 0)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		delivery := deliveries[0]
+		assert.Equal(t, merchantwebhooks.StatusPending, delivery.Status)
+		assert.Equal(t, http.StatusInternalServerError, delivery.LastStatusCode)
+		require.NotNil(t, delivery.NextAttemptAt)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), *delivery.NextAttemptAt, 5*time.Second)
+
+		// Not due yet
+		result, err = service.DeliverDue(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 0, result.Attempted)
+
+		for i := 0; i < 2; i++ {
+			store.makeDue(delivery.ID)
+			_, err = service.DeliverDue(context.Background())
+			require.NoError(t, err)
+		}
+
+		failed, err := service.GetDelivery(ctx, "tenant_1", delivery.ID)
+		require.NoError(t, err)
+		assert.Equal(t, merchantwebhooks.StatusFailed, failed.Status)
+		assert.Equal(t, 3, failed.Attempts)
+		assert.Nil(t, failed.NextAttemptAt)
+		require.Len(t, failed.Log, 3)
+		assert.Equal(t, http.StatusInternalServerError, failed.Log[0].StatusCode)
+	})
+
+	t.Run("should redeliver failed deliveries on request", func(t *testing.T) {
+		service, store := setup(nil)
+
+		var healthy atomic.Bool
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !healthy.Load() {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}))
+		defer server.Close()
+
+		_, err := service.CreateEndpoint(ctx, "tenant_1", merchantwebhooks.CreateEndpointRequest{URL: server.URL})
+		require.NoError(t, err)
+		require.NoError(t, service.Publish(ctx, event("payments.charge.succeeded")))
+		_, err = service.DeliverDue(context.Background())
+		require.NoError(t, err)
+
+		var deliveryID string
+		for id := range store.deliveries {
+			deliveryID = id
+		}
+
+		healthy.Store(true)
+		delivery, err := service.Redeliver(ctx, "tenant_1", deliveryID)
+		require.NoError(t, err)
+		assert.Equal(t, merchantwebhooks.StatusSucceeded, delivery.Status)
+		assert.Equal(t, 1, delivery.Attempts, "manual attempts do not count toward the automatic ones")
+		require.Len(t, delivery.Log, 2)
+		assert.True(t, delivery.Log[1].Manual)
+
+		_, err = service.Redeliver(tenancy.WithTenant(context.Background(), "tenant_2"), "tenant_2", deliveryID)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("should fail deliveries to disabled endpoints", func(t *testing.T) {
+		service, _ := setup(nil)
+
+		endpoint, err := service.CreateEndpoint(ctx, "tenant_1", merchantwebhooks.CreateEndpointRequest{URL: "https://example.com/hooks"})
+		require.NoError(t, err)
+		require.NoError(t, service.Publish(ctx, event("payments.charge.succeeded")))
+
+		disabled := false
+		_, err = service.UpdateEndpoint(ctx, "tenant_1", endpoint.ID, merchantwebhooks.UpdateEndpointRequest{Enabled: &disabled})
+		require.NoError(t, err)
+
+		result, err := service.DeliverDue(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Failed)
+
+		// Disabled endpoints are not queued new events
+		require.NoError(t, service.Publish(ctx, event("payments.refund.created")))
+		deliveries, err := service.ListDeliveries(ctx, "tenant_1", endpoint.ID, "", 0)
+		require.NoError(t, err)
+		assert.Len(t, deliveries, 1)
+	})
+
+	t.Run("should reject endpoints without https", func(t *testing.T) {
+		service, _ := setup(&merchantwebhooks.Config{Timeout: time.Second})
+
+		_, err := service.CreateEndpoint(ctx, "tenant_1", merchantwebhooks.CreateEndpointRequest{URL: "http://example.com/hooks"})
+		assert.ErrorIs(t, err, merchantwebhooks.ErrInvalidEndpoint)
+
+		_, err = service.CreateEndpoint(ctx, "tenant_1", merchantwebhooks.CreateEndpointRequest{URL: "/hooks"})
+		assert.ErrorIs(t, err, merchantwebhooks.ErrInvalidEndpoint)
+
+		_, err = service.CreateEndpoint(ctx, "tenant_1", merchantwebhooks.CreateEndpointRequest{URL: "https://example.com/hooks", EventTypes: []string{"payments.*.created"}})
+		assert.ErrorIs(t, err, merchantwebhooks.ErrInvalidEndpoint)
+	})
+
+	t.Run("should refuse private addresses and redirects", func(t *testing.T) {
+		service, store := setup(&merchantwebhooks.Config{Enabled: true, MaxAttempts: 3, Backoff: time.Minute, MaxBackoff: time.Hour, Timeout: time.Second, BatchSize: 10, AllowHTTP: true})
+
+		for _, url := range []string{"http://127.0.0.1:9090/admin", "http://169.254.169.254/latest/meta-data", "http://10.0.0.5/hooks", "http://[::1]/hooks", "http://localhost/hooks"} {
+			_, err := service.CreateEndpoint(ctx, "tenant_1", merchantwebhooks.CreateEndpointRequest{URL: url})
+			assert.ErrorIs(t, err, merchantwebhooks.ErrInvalidEndpoint, url)
+		}
+
+		// Addresses are checked again when the delivery connects, as if the
+		// endpoint's hostname had come to resolve to a private address
+		var reached atomic.Bool
+		internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			reached.Store(true)
+		}))
+		defer internal.Close()
+		endpoint, err := service.CreateEndpoint(ctx, "tenant_1", merchantwebhooks.CreateEndpointRequest{URL: "https://example.com/hooks"})
+		require.NoError(t, err)
+		store.endpoints[endpoint.ID].URL = internal.URL
+		require.NoError(t, service.Publish(ctx, event("payments.charge.succeeded")))
+
+		result, err := service.DeliverDue(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Retrying)
+		assert.False(t, reached.Load())
+		require.Len(t, store.attempts, 1)
+		assert.Contains(t, store.attempts[0].Error, merchantwebhooks.ErrPrivateAddress.Error())
+	})
+
+	t.Run("should not follow redirects", func(t *testing.T) {
+		service, store := setup(nil)
+
+		var reached atomic.Bool
+		internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			reached.Store(true)
+		}))
+		defer internal.Close()
+		server := httptest.NewServer(http.RedirectHandler(internal.URL, http.StatusFound))
+		defer server.Close()
+
+		_, err := service.CreateEndpoint(ctx, "tenant_1", merchantwebhooks.CreateEndpointRequest{URL: server.URL})
+		require.NoError(t, err)
+		require.NoError(t, service.Publish(ctx, event("payments.charge.succeeded")))
+
+		result, err := service.DeliverDue(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Retrying)
+		assert.False(t, reached.Load())
+		require.Len(t, store.attempts, 1)
+		assert.Equal(t, http.StatusFound, store.attempts[0].StatusCode)
+	})
+
+	t.Run("should reject stale signatures", func(t *testing.T) {
+		payload := []byte(`{"id":"evt_1"}`)
+		header := merchantwebhooks.Sign("whsec_test", time.Now().Add(-10*time.Minute), payload)
+
+		assert.NoError(t, merchantwebhooks.Verify("whsec_test", header, payload, 0))
+		assert.ErrorIs(t, merchantwebhooks.Verify("whsec_test", header, payload, 5*time.Minute), merchantwebhooks.ErrInvalidSignature)
+		assert.ErrorIs(t, merchantwebhooks.Verify("whsec_test", header, []byte(`{"id":"evt_2"}`), 0), merchantwebhooks.ErrInvalidSignature)
+	})
+}
+
+// MockMerchantWebhookStore keeps webhook endpoints, deliveries and attempts
+// in memory
+type MockMerchantWebhookStore struct {
+	mu         sync.Mutex
+	endpoints  map[string]*merchantwebhooks.Endpoint
+	deliveries map[string]*merchantwebhooks.Delivery
+	attempts   []*merchantwebhooks.Attempt
+}
+
+func NewMockMerchantWebhookStore() *MockMerchantWebhookStore {
+	return &MockMerchantWebhookStore{
+		endpoints:  make(map[string]*merchantwebhooks.Endpoint),
+		deliveries: make(map[string]*merchantwebhooks.Delivery),
+	}
+}
+
+// makeDue moves a pending delivery's next attempt to now
+func (m *MockMerchantWebhookStore) makeDue(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	m.deliveries[id].NextAttemptAt = &now
+}
+
+func (m *MockMerchantWebhookStore) CreateWebhookEndpoint(ctx context.Context, endpoint *merchantwebhooks.Endpoint) (*merchantwebhooks.Endpoint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	endpoint.CreatedAt = time.Now()
+	stored := *endpoint
+	m.endpoints[endpoint.ID] = &stored
+	copied := stored
+	return &copied, nil
+}
+
+func (m *MockMerchantWebhookStore) GetWebhookEndpoint(ctx context.Context, tenantID, id string) (*merchantwebhooks.Endpoint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	endpoint, ok := m.endpoints[id]
+	if !ok || endpoint.TenantID != tenantID {
+		return nil, sql.ErrNoRows
+	}
+	copied := *endpoint
+	return &copied, nil
+}
+
+func (m *MockMerchantWebhookStore) ListWebhookEndpoints(ctx context.Context, tenantID string) ([]*merchantwebhooks.Endpoint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var endpoints []*merchantwebhooks.Endpoint
+	for _, endpoint := range m.endpoints {
+		if endpoint.TenantID == tenantID {
+			copied := *endpoint
+			endpoints = append(endpoints, &copied)
+		}
+	}
+	return endpoints, nil
+}
+
+func (m *MockMerchantWebhookStore) UpdateWebhookEndpoint(ctx context.Context, endpoint *merchantwebhooks.Endpoint) (*merchantwebhooks.Endpoint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.endpoints[endpoint.ID]; !ok {
+		return nil, sql.ErrNoRows
+	}
+	stored := *endpoint
+	m.endpoints[endpoint.ID] = &stored
+	copied := stored
+	return &copied, nil
+}
+
+func (m *MockMerchantWebhookStore) DeleteWebhookEndpoint(ctx context.Context, tenantID, id string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	endpoint, ok := m.endpoints[id]
+	if !ok || endpoint.TenantID != tenantID {
+		return false, nil
+	}
+	delete(m.endpoints, id)
+	return true, nil
+}
+
+func (m *MockMerchantWebhookStore) CreateWebhookDelivery(ctx context.Context, delivery *merchantwebhooks.Delivery) (*merchantwebhooks.Delivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, existing := range m.deliveries {
+		if existing.EndpointID == delivery.EndpointID && existing.EventID == delivery.EventID {
+			copied := *existing
+			return &copied, nil
+		}
+	}
+	delivery.CreatedAt = time.Now()
+	stored := *delivery
+	m.deliveries[delivery.ID] = &stored
+	copied := stored
+	return &copied, nil
+}
+
+func (m *MockMerchantWebhookStore) GetWebhookDelivery(ctx context.Context, tenantID, id string) (*merchantwebhooks.Delivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delivery, ok := m.deliveries[id]
+	if !ok || delivery.TenantID != tenantID {
+		return nil, sql.ErrNoRows
+	}
+	copied := *delivery
+	return &copied, nil
+}
+
+func (m *MockMerchantWebhookStore) ListWebhookDeliveries(ctx context.Context, tenantID, endpointID, status string, limit int) ([]*merchantwebhooks.Delivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var deliveries []*merchantwebhooks.Delivery
+	for _, delivery := range m.deliveries {
+		if delivery.TenantID == tenantID && delivery.EndpointID == endpointID && (status == "" || delivery.Status == status) && len(deliveries) < limit {
+			copied := *delivery
+			deliveries = append(deliveries, &copied)
+		}
+	}
+	return deliveries, nil
+}
+
+func (m *MockMerchantWebhookStore) ClaimDueWebhookDeliveries(ctx context.Context, leaseUntil time.Time, limit int) ([]*merchantwebhooks.Delivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var deliveries []*merchantwebhooks.Delivery
+	for _, delivery := range m.deliveries {
+		if delivery.Status != merchantwebhooks.StatusPending || delivery.NextAttemptAt == nil || delivery.NextAttemptAt.After(time.Now()) || len(deliveries) >= limit {
+			continue
+		}
+		delivery.NextAttemptAt = &leaseUntil
+		copied := *delivery
+		deliveries = append(deliveries, &copied)
+	}
+	return deliveries, nil
+}
+
+func (m *MockMerchantWebhookStore) UpdateWebhookDelivery(ctx context.Context, delivery *merchantwebhooks.Delivery) (*merchantwebhooks.Delivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.deliveries[delivery.ID]; !ok {
+		return nil, sql.ErrNoRows
+	}
+	stored := *delivery
+	stored.Log = nil
+	m.deliveries[delivery.ID] = &stored
+	copied := stored
+	return &copied, nil
+}
+
+func (m *MockMerchantWebhookStore) CreateWebhookDeliveryAttempt(ctx context.Context, attempt *merchantwebhooks.Attempt) (*merchantwebhooks.Attempt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.attempts = append(m.attempts, attempt)
+	return attempt, nil
+}
+
+func (m *MockMerchantWebhookStore) ListWebhookDeliveryAttempts(ctx context.Context, deliveryID string) ([]*merchantwebhooks.Attempt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var attempts []*merchantwebhooks.Attempt
+	for _, attempt := range m.attempts {
+		if attempt.DeliveryID == deliveryID {
+			attempts = append(attempts, attempt)
+		}
+	}
+	return attempts, nil
+}
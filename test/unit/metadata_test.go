@@ -0,0 +1,151 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"apis/payments/services/metadata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMetadataSchemas tests validating resource metadata against tenant schemas
+func TestMetadataSchemas(t *testing.T) {
+	noAdditional := false
+	orderSchema := func() *metadata.Schema {
+		return &metadata.Schema{
+			Type: "object",
+			Properties: map[string]*metadata.Property{
+				"order_id": {Type: metadata.TypeString, Pattern: "^ord_"},
+				"quantity": {Type: metadata.TypeInteger},
+				"channel":  {Enum: []string{"web", "pos"}},
+			},
+			Required:             []string{"order_id"},
+			AdditionalProperties: &noAdditional,
+		}
+	}
+
+	t.Run("should accept metadata matching the schema", func(t *testing.T) {
+		service := metadata.NewService(NewMockMetadataStore())
+		_, err := service.RegisterSchema(context.Background(), "tenant_1", metadata.ResourceCharge, orderSchema())
+		require.NoError(t, err)
+
+		err = service.Validate(context.Background(), "tenant_1", metadata.ResourceCharge, map[string]string{
+			"order_id": "ord_123",
+			"quantity": "2",
+			"channel":  "web",
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("should reject typo'd keys when additional properties are disallowed", func(t *testing.T) {
+		service := metadata.NewService(NewMockMetadataStore())
+		_, err := service.RegisterSchema(context.Background(), "tenant_1", metadata.ResourceCharge, orderSchema())
+		require.NoError(t, err)
+
+		err = service.Validate(context.Background(), "tenant_1", metadata.ResourceCharge, map[string]string{
+			"oder_id": "ord_123",
+		})
+		require.ErrorIs(t, err, metadata.ErrInvalidMetadata)
+		assert.Contains(t, err.Error(), "order_id is required")
+		assert.Contains(t, err.Error(), "oder_id is not an allowed key")
+	})
+
+	t.Run("should report type, pattern and enum violations", func(t *testing.T) {
+		service := metadata.NewService(NewMockMetadataStore())
+		_, err := service.RegisterSchema(context.Background(), "tenant_1", metadata.ResourceCharge, orderSchema())
+		require.NoError(t, err)
+
+		err = service.Validate(context.Background(), "tenant_1", metadata.ResourceCharge, map[string]string{
+			"order_id": "123",
+			"quantity": "two",
+			"channel":  "phone",
+		})
+		require.ErrorIs(t, err, metadata.ErrInvalidMetadata)
+		assert.Contains(t, err.Error(), "order_id must match ^ord_")
+		assert.Contains(t, err.Error(), "quantity must be an integer")
+		assert.Contains(t, err.Error(), "channel must be one of web, pos")
+	})
+
+	t.Run("should allow unknown keys unless additional properties are disallowed", func(t *testing.T) {
+		schema := orderSchema()
+		schema.AdditionalProperties = nil
+		require.NoError(t, schema.Compile())
+
+		assert.NoError(t, schema.Validate(map[string]string{"order_id": "ord_1", "note": "gift"}))
+	})
+
+	t.Run("should not require keys on partial updates", func(t *testing.T) {
+		schema := orderSchema()
+		require.NoError(t, schema.Compile())
+
+		assert.NoError(t, schema.ValidatePartial(map[string]string{"quantity": "3"}))
+		assert.ErrorIs(t, schema.ValidatePartial(map[string]string{"quantity": "x"}), metadata.ErrInvalidMetadata)
+	})
+
+	t.Run("should accept any metadata when no schema is registered", func(t *testing.T) {
+		service := metadata.NewService(NewMockMetadataStore())
+
+		err := service.Validate(context.Background(), "tenant_1", metadata.ResourceCustomer, map[string]string{"anything": "goes"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("should reject invalid schemas", func(t *testing.T) {
+		service := metadata.NewService(NewMockMetadataStore())
+
+		_, err := service.RegisterSchema(context.Background(), "tenant_1", "invoice", orderSchema())
+		assert.ErrorIs(t, err, metadata.ErrInvalidSchema)
+
+		bad := orderSchema()
+		bad.Properties["order_id"].Pattern = "("
+		_, err = service.RegisterSchema(context.Background(), "tenant_1", metadata.ResourceCharge, bad)
+		assert.ErrorIs(t, err, metadata.ErrInvalidSchema)
+
+		bad = orderSchema()
+		bad.Required = []string{"missing"}
+		_, err = service.RegisterSchema(context.Background(), "tenant_1", metadata.ResourceCharge, bad)
+		assert.ErrorIs(t, err, metadata.ErrInvalidSchema)
+	})
+}
+
+// MockMetadataStore keeps metadata schemas in memory
+type MockMetadataStore struct {
+	schemas map[string]*metadata.Registration
+}
+
+// NewMockMetadataStore creates an empty metadata schema store
+func NewMockMetadataStore() *MockMetadataStore {
+	return &MockMetadataStore{schemas: make(map[string]*metadata.Registration)}
+}
+
+func (m *MockMetadataStore) GetMetadataSchema(ctx context.Context, tenantID, resource string) (*metadata.Registration, error) {
+	registration, ok := m.schemas[tenantID+"/"+resource]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return registration, nil
+}
+
+func (m *MockMetadataStore) ListMetadataSchemas(ctx context.Context, tenantID string) ([]*metadata.Registration, error) {
+	var registrations []*metadata.Registration
+	for _, registration := range m.schemas {
+		if registration.TenantID == tenantID {
+			registrations = append(registrations, registration)
+		}
+	}
+	return registrations, nil
+}
+
+func (m *MockMetadataStore) UpsertMetadataSchema(ctx context.Context, registration *metadata.Registration) (*metadata.Registration, error) {
+	m.schemas[registration.TenantID+"/"+registration.Resource] = registration
+	return registration, nil
+}
+
+func (m *MockMetadataStore) DeleteMetadataSchema(ctx context.Context, tenantID, resource string) (bool, error) {
+	key := tenantID + "/" + resource
+	_, ok := m.schemas[key]
+	delete(m.schemas, key)
+	return ok, nil
+}
@@ -0,0 +1,237 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"apis/payments/services/mirror"
+	"apis/payments/services/stripe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// TestMirror tests keeping the local copy of provider objects up to date
+func TestMirror(t *testing.T) {
+	setup := func() (*mirror.Service, *stripe.WebhookService, *MockMirrorStore) {
+		store := NewMockMirrorStore()
+		service := mirror.NewService(store)
+		webhooks := stripe.NewWebhookService("whsec_test")
+		service.RegisterWebhookHandlers(webhooks)
+		return service, webhooks, store
+	}
+
+	newEvent := func(eventType stripego.EventType, created int64, raw string) stripego.Event {
+		return stripego.Event{
+			ID:      "evt_1",
+			Type:    eventType,
+			Created: created,
+			Data:    &stripego.EventData{Raw: []byte(raw)},
+		}
+	}
+
+	t.Run("should store objects reported after mutations", func(t *testing.T) {
+		service, _, store := setup()
+
+		service.SaveCharge(context.Background(), &stripe.Charge{ID: "ch_1", Status: "succeeded"})
+		service.SaveRefund(context.Background(), &stripe.Refund{ID: "re_1", ChargeID: "ch_1"})
+		service.SaveSubscription(context.Background(), &stripe.Subscription{ID: "sub_1", Status: "active"})
+
+		assert.Equal(t, "succeeded", store.charges["ch_1"].Status)
+		assert.Equal(t, "ch_1", store.refunds["re_1"].ChargeID)
+		assert.Equal(t, "active", store.subscriptions["sub_1"].Status)
+	})
+
+	t.Run("should apply provider events with the event time", func(t *testing.T) {
+		_, webhooks, store := setup()
+
+		require.NoError(t, webhooks.Dispatch(context.Background(), newEvent(stripego.EventTypeChargeRefunded, 1700000200,
+			`{"id": "ch_1", "status": "succeeded", "amount": 1000, "amount_refunded": 1000, "refunded": true}`)))
+		require.NoError(t, webhooks.Dispatch(context.Background(), newEvent(stripego.EventTypeChargeSucceeded, 1700000100,
+			`{"id": "ch_1", "status": "succeeded", "amount": 1000}`)))
+		require.NoError(t, webhooks.Dispatch(context.Background(), newEvent(stripego.EventTypeRefundCreated, 1700000200,
+			`{"id": "re_1", "charge": "ch_1", "amount": 1000, "status": "succeeded"}`)))
+
+		assert.True(t, store.charges["ch_1"].Refunded)
+		assert.Equal(t, time.Unix(1700000200, 0).UTC(), store.syncedAt["ch_1"])
+		assert.Equal(t, "ch_1", store.refunds["re_1"].ChargeID)
+	})
+
+	t.Run("should remove detached payment methods and deleted customers", func(t *testing.T) {
+		_, webhooks, store := setup()
+
+		require.NoError(t, webhooks.Dispatch(context.Background(), newEvent(stripego.EventTypeCustomerCreated, 1700000000,
+			`{"id": "cus_1", "email": "jane@example.com"}`)))
+		require.NoError(t, webhooks.Dispatch(context.Background(), newEvent(stripego.EventTypePaymentMethodAttached, 1700000000,
+			`{"id": "pm_1", "type": "card", "customer": "cus_1"}`)))
+		require.NoError(t, webhooks.Dispatch(context.Background(), newEvent(stripego.EventTypePaymentMethodAttached, 1700000000,
+			`{"id": "pm_2", "type": "card"}`)))
+		assert.Contains(t, store.customers, "cus_1")
+		assert.Contains(t, store.paymentMethods, "pm_1")
+		assert.NotContains(t, store.paymentMethods, "pm_2")
+
+		require.NoError(t, webhooks.Dispatch(context.Background(), newEvent(stripego.EventTypePaymentMethodDetached, 1700000100,
+			`{"id": "pm_1", "type": "card"}`)))
+		require.NoError(t, webhooks.Dispatch(context.Background(), newEvent(stripego.EventTypeCustomerDeleted, 1700000100,
+			`{"id": "cus_1"}`)))
+
+		assert.NotContains(t, store.paymentMethods, "pm_1")
+		assert.NotContains(t, store.customers, "cus_1")
+	})
+
+	t.Run("should ignore one-off prices and deactivate deleted plans", func(t *testing.T) {
+		_, webhooks, store := setup()
+
+		require.NoError(t, webhooks.Dispatch(context.Background(), newEvent(stripego.EventTypePriceCreated, 1700000000,
+			`{"id": "price_once", "product": "prod_1", "unit_amount": 500, "active": true}`)))
+		require.NoError(t, webhooks.Dispatch(context.Background(), newEvent(stripego.EventTypePriceCreated, 1700000000,
+			`{"id": "price_pro", "product": "prod_1", "unit_amount": 2000, "active": true, "recurring": {"interval": "month", "interval_count": 1}}`)))
+		assert.NotContains(t, store.plans, "price_once")
+		assert.True(t, store.plans["price_pro"].Active)
+
+		require.NoError(t, webhooks.Dispatch(context.Background(), newEvent(stripego.EventTypePriceDeleted, 1700000100,
+			`{"id": "price_pro", "product": "prod_1", "unit_amount": 2000, "active": true, "recurring": {"interval": "month", "interval_count": 1}}`)))
+		assert.False(t, store.plans["price_pro"].Active)
+	})
+
+	t.Run("should not fail mutations when the mirror write fails", func(t *testing.T) {
+		service, _, store := setup()
+		store.err = errors.New("connection refused")
+
+		assert.NotPanics(t, func() {
+			service.SaveCustomer(context.Background(), &stripe.Customer{ID: "cus_1"})
+			service.RemovePaymentMethod(context.Background(), "pm_1")
+		})
+		assert.Empty(t, store.customers)
+	})
+}
+
+// MockMirrorStore is an in-memory mirror.Store that keeps only the newest version of each object
+type MockMirrorStore struct {
+	customers      map[string]*stripe.Customer
+	paymentMethods map[string]*stripe.PaymentMethod
+	charges        map[string]*stripe.Charge
+	refunds        map[string]*stripe.Refund
+	subscriptions  map[string]*stripe.Subscription
+	plans          map[string]*stripe.SubscriptionPlan
+	syncedAt       map[string]time.Time
+	err            error
+}
+
+// NewMockMirrorStore creates a new mock mirror store
+func NewMockMirrorStore() *MockMirrorStore {
+	return &MockMirrorStore{
+		customers:      make(map[string]*stripe.Customer),
+		paymentMethods: make(map[string]*stripe.PaymentMethod),
+		charges:        make(map[string]*stripe.Charge),
+		refunds:        make(map[string]*stripe.Refund),
+		subscriptions:  make(map[string]*stripe.Subscription),
+		plans:          make(map[string]*stripe.SubscriptionPlan),
+		syncedAt:       make(map[string]time.Time),
+	}
+}
+
+// newer records syncedAt for id and reports whether it is the newest version seen
+func (m *MockMirrorStore) newer(id string, syncedAt time.Time) bool {
+	if last, ok := m.syncedAt[id]; ok && last.After(syncedAt) {
+		return false
+	}
+	m.syncedAt[id] = syncedAt
+	return true
+}
+
+func (m *MockMirrorStore) UpsertMirroredCustomer(ctx context.Context, customer *stripe.Customer, syncedAt time.Time) error {
+	if m.err != nil {
+		return m.err
+	}
+	if m.newer(customer.ID, syncedAt) {
+		m.customers[customer.ID] = customer
+	}
+	return nil
+}
+
+func (m *MockMirrorStore) DeleteMirroredCustomer(ctx context.Context, customerID string) error {
+	if m.err != nil {
+		return m.err
+	}
+	for id, paymentMethod := range m.paymentMethods {
+		if paymentMethod.Customer == customerID {
+			delete(m.paymentMethods, id)
+		}
+	}
+	delete(m.customers, customerID)
+	return nil
+}
+
+func (m *MockMirrorStore) UpsertMirroredPaymentMethod(ctx context.Context, paymentMethod *stripe.PaymentMethod, syncedAt time.Time) error {
+	if m.err != nil {
+		return m.err
+	}
+	if m.newer(paymentMethod.ID, syncedAt) {
+		m.paymentMethods[paymentMethod.ID] = paymentMethod
+	}
+	return nil
+}
+
+func (m *MockMirrorStore) DeleteMirroredPaymentMethod(ctx context.Context, paymentMethodID string, syncedAt time.Time) error {
+	if m.err != nil {
+		return m.err
+	}
+	if m.newer(paymentMethodID, syncedAt) {
+		delete(m.paymentMethods, paymentMethodID)
+	}
+	return nil
+}
+
+func (m *MockMirrorStore) UpsertMirroredCharge(ctx context.Context, charge *stripe.Charge, syncedAt time.Time) error {
+	if m.err != nil {
+		return m.err
+	}
+	if m.newer(charge.ID, syncedAt) {
+		m.charges[charge.ID] = charge
+	}
+	return nil
+}
+
+func (m *MockMirrorStore) MarkMirroredChargeDisputed(ctx context.Context, chargeID string) error {
+	if m.err != nil {
+		return m.err
+	}
+	if charge, ok := m.charges[chargeID]; ok {
+		charge.Disputed = true
+	}
+	return nil
+}
+
+func (m *MockMirrorStore) UpsertMirroredRefund(ctx context.Context, refund *stripe.Refund, syncedAt time.Time) error {
+	if m.err != nil {
+		return m.err
+	}
+	if m.newer(refund.ID, syncedAt) {
+		m.refunds[refund.ID] = refund
+	}
+	return nil
+}
+
+func (m *MockMirrorStore) UpsertSubscription(ctx context.Context, subscription *stripe.Subscription, syncedAt time.Time) error {
+	if m.err != nil {
+		return m.err
+	}
+	if m.newer(subscription.ID, syncedAt) {
+		m.subscriptions[subscription.ID] = subscription
+	}
+	return nil
+}
+
+func (m *MockMirrorStore) UpsertSubscriptionPlan(ctx context.Context, plan *stripe.SubscriptionPlan, syncedAt time.Time) error {
+	if m.err != nil {
+		return m.err
+	}
+	if m.newer(plan.ID, syncedAt) {
+		m.plans[plan.ID] = plan
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package test
+
+import (
+	"testing"
+
+	"apis/payments/services/money"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDecimalAmounts tests exact conversion between decimal strings and minor
+// units and the display metadata derived from them
+func TestDecimalAmounts(t *testing.T) {
+	t.Run("should parse decimal amounts into minor units", func(t *testing.T) {
+		cases := []struct {
+			amount   string
+			currency string
+			minor    int64
+		}{
+			{"10.00", "usd", 1000},
+			{"10", "usd", 1000},
+			{"10.5", "eur", 1050},
+			{"0.01", "usd", 1},
+			{"0.29", "usd", 29}, // 0.29 * 100 is 28.999... as a float
+			{"1000", "jpy", 1000},
+			{"1.234", "kwd", 1234},
+			{"10.000", "USD", 1000}, // trailing zeros are exact
+		}
+
+		for _, tc := range cases {
+			minor, err := money.ParseDecimal(tc.amount, tc.currency)
+			require.NoError(t, err, tc.amount)
+			assert.Equal(t, tc.minor, minor, tc.amount)
+		}
+	})
+
+	t.Run("should reject amounts that would need rounding or are malformed", func(t *testing.T) {
+		cases := []struct {
+			amount   string
+			currency string
+		}{
+			{"10.001", "usd"},
+			{"10.5", "jpy"},
+			{"-10.00", "usd"},
+			{"1e3", "usd"},
+			{".50", "usd"},
+			{"5.", "usd"},
+			{"", "usd"},
+			{"1,000.00", "usd"},
+			{"99999999999999999999", "usd"},
+			{"10.00", ""},
+		}
+
+		for _, tc := range cases {
+			_, err := money.ParseDecimal(tc.amount, tc.currency)
+			assert.ErrorIs(t, err, money.ErrInvalidDecimal, tc.amount)
+		}
+	})
+
+	t.Run("should format minor units as decimals", func(t *testing.T) {
+		assert.Equal(t, "10.00", money.FormatDecimal(1000, "usd"))
+		assert.Equal(t, "0.05", money.FormatDecimal(5, "usd"))
+		assert.Equal(t, "1000", money.FormatDecimal(1000, "jpy"))
+		assert.Equal(t, "1.234", money.FormatDecimal(1234, "kwd"))
+		assert.Equal(t, "-0.50", money.FormatDecimal(-50, "usd"))
+	})
+
+	t.Run("should describe amounts with each currency's exponent and symbol", func(t *testing.T) {
+		assert.Equal(t, money.Display{Decimal: "1234.56", Exponent: 2, Symbol: "$", Formatted: "$1,234.56"}, money.Describe(123456, "usd"))
+		assert.Equal(t, money.Display{Decimal: "1234567", Exponent: 0, Symbol: "¥", Formatted: "¥1,234,567"}, money.Describe(1234567, "JPY"))
+		assert.Equal(t, money.Display{Decimal: "1234.567", Exponent: 3, Symbol: "KD", Formatted: "KD 1,234.567"}, money.Describe(1234567, "kwd"))
+		assert.Equal(t, money.Display{Decimal: "15000", Exponent: 0, Symbol: "$", Formatted: "$15.000"}, money.Describe(15000, "clp"))
+		assert.Equal(t, "1.234,56 €", money.Describe(123456, "eur").Formatted)
+		assert.Equal(t, "-£0.50", money.Describe(-50, "gbp").Formatted)
+		assert.Equal(t, "100.00 XYZ", money.Describe(10000, "xyz").Formatted)
+	})
+
+	t.Run("should require minor units and decimal amounts to agree", func(t *testing.T) {
+		minor, err := money.ResolveAmount(1000, "10.00", "usd")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1000), minor)
+
+		_, err = money.ResolveAmount(1000, "10.01", "usd")
+		assert.ErrorIs(t, err, money.ErrAmountMismatch)
+
+		minor, err = money.ResolveAmount(1000, "", "usd")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1000), minor)
+	})
+}
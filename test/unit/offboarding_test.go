@@ -0,0 +1,415 @@
+package test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"apis/payments/services/mirror"
+	"apis/payments/services/offboarding"
+	"apis/payments/services/stripe"
+	"apis/payments/services/vault"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOffboarding tests building encrypted offboarding export archives and
+// requesting PAN migrations for them
+func TestOffboarding(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	publicKey := string(pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PUBLIC KEY",
+		Bytes: x509.MarshalPKCS1PublicKey(&privateKey.PublicKey),
+	}))
+
+	config := &offboarding.Config{Interval: time.Minute, BatchSize: 5}
+
+	// readArchive decrypts a sealed archive and returns its files
+	readArchive := func(t *testing.T, sealed []byte) map[string][]byte {
+		archive, err := offboarding.Open(sealed, privateKey)
+		require.NoError(t, err)
+
+		gz, err := gzip.NewReader(bytes.NewReader(archive))
+		require.NoError(t, err)
+		reader := tar.NewReader(gz)
+		files := make(map[string][]byte)
+		for {
+			header, err := reader.Next()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			content, err := io.ReadAll(reader)
+			require.NoError(t, err)
+			files[header.Name] = content
+		}
+		return files
+	}
+
+	// completedExport creates an export and builds it
+	completedExport := func(t *testing.T, service *offboarding.Service) *offboarding.Export {
+		export, err := service.Create(context.Background(), "acme", offboarding.CreateRequest{RecipientPublicKey: publicKey})
+		require.NoError(t, err)
+		completed, err := service.RunPending(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 1, completed)
+		return export
+	}
+
+	t.Run("should open what it seals", func(t *testing.T) {
+		sealed, err := offboarding.Seal([]byte("archive"), &privateKey.PublicKey)
+		require.NoError(t, err)
+		assert.NotContains(t, string(sealed), "archive")
+
+		opened, err := offboarding.Open(sealed, privateKey)
+		require.NoError(t, err)
+		assert.Equal(t, "archive", string(opened))
+
+		tampered := bytes.Clone(sealed)
+		tampered[len(tampered)-1] ^= 1
+		_, err = offboarding.Open(tampered, privateKey)
+		assert.Error(t, err)
+
+		_, err = offboarding.Open([]byte("not an archive"), privateKey)
+		assert.ErrorIs(t, err, offboarding.ErrInvalidArchive)
+	})
+
+	t.Run("should reject unusable recipient keys", func(t *testing.T) {
+		service := offboarding.NewService(NewMockOffboardingStore(), &MockMigrationHook{}, config)
+
+		_, err := service.Create(context.Background(), "acme", offboarding.CreateRequest{RecipientPublicKey: "not a key"})
+		assert.ErrorIs(t, err, offboarding.ErrInvalidRecipientKey)
+
+		weakKey, err := rsa.GenerateKey(rand.Reader, 1024)
+		require.NoError(t, err)
+		der, err := x509.MarshalPKIXPublicKey(&weakKey.PublicKey)
+		require.NoError(t, err)
+		_, err = service.Create(context.Background(), "acme", offboarding.CreateRequest{
+			RecipientPublicKey: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+		})
+		assert.ErrorIs(t, err, offboarding.ErrInvalidRecipientKey)
+	})
+
+	t.Run("should package the tenant's data into an encrypted archive with a manifest", func(t *testing.T) {
+		store := NewMockOffboardingStore()
+		service := offboarding.NewService(store, &MockMigrationHook{}, config)
+
+		export, err := service.Create(context.Background(), "acme", offboarding.CreateRequest{RecipientPublicKey: publicKey})
+		require.NoError(t, err)
+		assert.Equal(t, offboarding.StatusPending, export.Status)
+
+		_, _, err = service.Archive(context.Background(), export.ID)
+		assert.ErrorIs(t, err, offboarding.ErrNotCompleted)
+
+		completed, err := service.RunPending(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, completed)
+
+		export, sealed, err := service.Archive(context.Background(), export.ID)
+		require.NoError(t, err)
+		assert.Equal(t, offboarding.StatusCompleted, export.Status)
+		sum := sha256.Sum256(sealed)
+		assert.Equal(t, hex.EncodeToString(sum[:]), export.ArchiveSHA256)
+		assert.Equal(t, int64(len(sealed)), export.ArchiveSize)
+
+		files := readArchive(t, sealed)
+		var manifest offboarding.Manifest
+		require.NoError(t, json.Unmarshal(files["manifest.json"], &manifest))
+		assert.Equal(t, export.ID, manifest.ExportID)
+		assert.Equal(t, "acme", manifest.TenantID)
+		assert.NotEmpty(t, manifest.Omitted)
+
+		records := map[string]int{}
+		for _, file := range manifest.Files {
+			records[file.Name] = file.Records
+			content, ok := files[file.Name]
+			require.True(t, ok, file.Name)
+			sum := sha256.Sum256(content)
+			assert.Equal(t, hex.EncodeToString(sum[:]), file.SHA256, file.Name)
+		}
+		assert.Equal(t, map[string]int{
+			"customers.jsonl":       2,
+			"payment_methods.jsonl": 2,
+			"vault_tokens.jsonl":    1,
+			"subscriptions.jsonl":   1,
+			"charges.jsonl":         3,
+			"refunds.jsonl":         1,
+		}, records)
+		assert.NotContains(t, string(files["customers.jsonl"]), "cus_other")
+
+		var charge stripe.Charge
+		require.NoError(t, json.Unmarshal([]byte(strings.Split(string(files["charges.jsonl"]), "\n")[0]), &charge))
+		assert.Equal(t, "ch_1", charge.ID)
+
+		completed, err = service.RunPending(context.Background())
+		require.NoError(t, err)
+		assert.Zero(t, completed)
+	})
+
+	t.Run("should fail exports whose data cannot be read", func(t *testing.T) {
+		store := NewMockOffboardingStore()
+		store.readErr = errors.New("database unavailable")
+		service := offboarding.NewService(store, &MockMigrationHook{}, config)
+
+		export, err := service.Create(context.Background(), "acme", offboarding.CreateRequest{RecipientPublicKey: publicKey})
+		require.NoError(t, err)
+
+		completed, err := service.RunPending(context.Background())
+		assert.Error(t, err)
+		assert.Zero(t, completed)
+
+		export, err = service.Get(context.Background(), export.ID)
+		require.NoError(t, err)
+		assert.Equal(t, offboarding.StatusFailed, export.Status)
+		assert.Contains(t, export.Error, "database unavailable")
+	})
+
+	t.Run("should hand PAN migrations to the hook once", func(t *testing.T) {
+		store := NewMockOffboardingStore()
+		hook := &MockMigrationHook{reference: "TICKET-1"}
+		service := offboarding.NewService(store, hook, config)
+		export := completedExport(t, service)
+
+		_, err := service.RequestPANMigration(context.Background(), export.ID, offboarding.MigrationRequest{})
+		assert.ErrorIs(t, err, offboarding.ErrDestinationRequired)
+
+		export, err = service.RequestPANMigration(context.Background(), export.ID, offboarding.MigrationRequest{Destination: "adyen"})
+		require.NoError(t, err)
+		assert.Equal(t, offboarding.MigrationRequested, export.PANMigrationStatus)
+		assert.Equal(t, "adyen", export.PANMigrationDestination)
+		assert.Equal(t, "TICKET-1", export.PANMigrationReference)
+
+		require.Len(t, hook.migrations, 1)
+		assert.Equal(t, []string{"cus_1", "cus_2"}, hook.migrations[0].CustomerIDs)
+		assert.Equal(t, []string{"pm_1", "pm_2"}, hook.migrations[0].PaymentMethodIDs)
+
+		_, err = service.RequestPANMigration(context.Background(), export.ID, offboarding.MigrationRequest{Destination: "adyen"})
+		assert.ErrorIs(t, err, offboarding.ErrMigrationRequested)
+	})
+
+	t.Run("should record failed PAN migrations so they can be retried", func(t *testing.T) {
+		store := NewMockOffboardingStore()
+		hook := &MockMigrationHook{err: errors.New("ticketing unavailable")}
+		service := offboarding.NewService(store, hook, config)
+		export := completedExport(t, service)
+
+		recorded, err := service.RequestPANMigration(context.Background(), export.ID, offboarding.MigrationRequest{Destination: "adyen"})
+		assert.Error(t, err)
+		require.NotNil(t, recorded)
+		assert.Equal(t, offboarding.MigrationFailed, recorded.PANMigrationStatus)
+
+		hook.err = nil
+		recorded, err = service.RequestPANMigration(context.Background(), export.ID, offboarding.MigrationRequest{Destination: "adyen"})
+		require.NoError(t, err)
+		assert.Equal(t, offboarding.MigrationRequested, recorded.PANMigrationStatus)
+	})
+
+	t.Run("should not migrate exports that have not completed", func(t *testing.T) {
+		service := offboarding.NewService(NewMockOffboardingStore(), &MockMigrationHook{}, config)
+
+		export, err := service.Create(context.Background(), "acme", offboarding.CreateRequest{RecipientPublicKey: publicKey})
+		require.NoError(t, err)
+
+		_, err = service.RequestPANMigration(context.Background(), export.ID, offboarding.MigrationRequest{Destination: "adyen"})
+		assert.ErrorIs(t, err, offboarding.ErrNotCompleted)
+
+		_, err = service.RequestPANMigration(context.Background(), "obx_missing", offboarding.MigrationRequest{Destination: "adyen"})
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+}
+
+// MockOffboardingStore is a mock implementation of offboarding.Store holding
+// two customers of tenant acme and one of another tenant
+type MockOffboardingStore struct {
+	exports  map[string]*offboarding.Export
+	archives map[string][]byte
+	readErr  error
+}
+
+// NewMockOffboardingStore creates a new mock offboarding store
+func NewMockOffboardingStore() *MockOffboardingStore {
+	return &MockOffboardingStore{
+		exports:  make(map[string]*offboarding.Export),
+		archives: make(map[string][]byte),
+	}
+}
+
+func (m *MockOffboardingStore) CreateOffboardingExport(ctx context.Context, export *offboarding.Export) (*offboarding.Export, error) {
+	stored := *export
+	stored.CreatedAt = time.Now()
+	m.exports[export.ID] = &stored
+	copied := stored
+	return &copied, nil
+}
+
+func (m *MockOffboardingStore) GetOffboardingExport(ctx context.Context, id string) (*offboarding.Export, error) {
+	export, ok := m.exports[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *export
+	return &copied, nil
+}
+
+func (m *MockOffboardingStore) ListOffboardingExports(ctx context.Context, tenantID string, limit int) ([]*offboarding.Export, error) {
+	var exports []*offboarding.Export
+	for _, export := range m.exports {
+		if export.TenantID == tenantID && len(exports) < limit {
+			copied := *export
+			exports = append(exports, &copied)
+		}
+	}
+	return exports, nil
+}
+
+func (m *MockOffboardingStore) ListRunnableOffboardingExports(ctx context.Context, limit int) ([]*offboarding.Export, error) {
+	var exports []*offboarding.Export
+	for _, export := range m.exports {
+		if export.Status == offboarding.StatusPending && len(exports) < limit {
+			copied := *export
+			exports = append(exports, &copied)
+		}
+	}
+	return exports, nil
+}
+
+func (m *MockOffboardingStore) ClaimOffboardingExport(ctx context.Context, id string) (*offboarding.Export, error) {
+	export, ok := m.exports[id]
+	if !ok || export.Status != offboarding.StatusPending {
+		return nil, sql.ErrNoRows
+	}
+	now := time.Now()
+	export.Status = offboarding.StatusRunning
+	export.StartedAt = &now
+	copied := *export
+	return &copied, nil
+}
+
+func (m *MockOffboardingStore) CompleteOffboardingExport(ctx context.Context, export *offboarding.Export, archive []byte) (*offboarding.Export, error) {
+	stored, ok := m.exports[export.ID]
+	if !ok || stored.Status != offboarding.StatusRunning {
+		return nil, sql.ErrNoRows
+	}
+	now := time.Now()
+	m.archives[export.ID] = archive
+	stored.Status = offboarding.StatusCompleted
+	stored.Manifest = export.Manifest
+	stored.ArchiveSHA256 = export.ArchiveSHA256
+	stored.ArchiveSize = export.ArchiveSize
+	stored.CompletedAt = &now
+	copied := *stored
+	return &copied, nil
+}
+
+func (m *MockOffboardingStore) FailOffboardingExport(ctx context.Context, id, reason string) (*offboarding.Export, error) {
+	stored, ok := m.exports[id]
+	if !ok || stored.Status != offboarding.StatusRunning {
+		return nil, sql.ErrNoRows
+	}
+	stored.Status = offboarding.StatusFailed
+	stored.Error = reason
+	copied := *stored
+	return &copied, nil
+}
+
+func (m *MockOffboardingStore) RecordOffboardingPANMigration(ctx context.Context, export *offboarding.Export) (*offboarding.Export, error) {
+	stored, ok := m.exports[export.ID]
+	if !ok || stored.Status != offboarding.StatusCompleted {
+		return nil, sql.ErrNoRows
+	}
+	now := time.Now()
+	stored.PANMigrationStatus = export.PANMigrationStatus
+	stored.PANMigrationDestination = export.PANMigrationDestination
+	stored.PANMigrationReference = export.PANMigrationReference
+	stored.PANMigrationRequestedAt = &now
+	copied := *stored
+	return &copied, nil
+}
+
+func (m *MockOffboardingStore) GetOffboardingArchive(ctx context.Context, id string) ([]byte, error) {
+	archive, ok := m.archives[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return archive, nil
+}
+
+func (m *MockOffboardingStore) ListTenantCustomerIDs(ctx context.Context, tenantID string) ([]string, error) {
+	if tenantID != "acme" {
+		return []string{"cus_other"}, nil
+	}
+	return []string{"cus_1", "cus_2"}, nil
+}
+
+func (m *MockOffboardingStore) GetCustomer(ctx context.Context, id string) (*stripe.Customer, error) {
+	if m.readErr != nil {
+		return nil, m.readErr
+	}
+	return &stripe.Customer{ID: id, Email: id + "@example.com"}, nil
+}
+
+func (m *MockOffboardingStore) ListPaymentMethods(ctx context.Context, customerID string) ([]*stripe.PaymentMethod, error) {
+	return []*stripe.PaymentMethod{{ID: "pm_" + strings.TrimPrefix(customerID, "cus_"), Type: "card"}}, nil
+}
+
+func (m *MockOffboardingStore) ListVaultTokensByCustomer(ctx context.Context, customerID string) ([]*vault.Token, error) {
+	if customerID != "cus_1" {
+		return nil, nil
+	}
+	return []*vault.Token{{ID: "pmt_1", Provider: "stripe", ProviderToken: "pm_1", CustomerID: customerID}}, nil
+}
+
+func (m *MockOffboardingStore) ListSubscriptions(ctx context.Context, filter mirror.SubscriptionFilter) ([]*stripe.Subscription, error) {
+	if filter.CustomerID != "cus_2" {
+		return nil, nil
+	}
+	return []*stripe.Subscription{{ID: "sub_1", CustomerID: filter.CustomerID, Status: "active"}}, nil
+}
+
+func (m *MockOffboardingStore) ListCharges(ctx context.Context, customerID string, limit, offset int32) ([]*stripe.Charge, error) {
+	if offset > 0 {
+		return nil, nil
+	}
+	if customerID == "cus_1" {
+		return []*stripe.Charge{
+			{ID: "ch_1", Amount: 1000, AmountRefunded: 500, CustomerID: customerID},
+			{ID: "ch_2", Amount: 2000, CustomerID: customerID},
+		}, nil
+	}
+	return []*stripe.Charge{{ID: "ch_3", Amount: 3000, CustomerID: customerID}}, nil
+}
+
+func (m *MockOffboardingStore) ListRefunds(ctx context.Context, chargeID string, limit, offset int32) ([]*stripe.Refund, error) {
+	return []*stripe.Refund{{ID: "re_" + chargeID, ChargeID: chargeID, Amount: 500}}, nil
+}
+
+// MockMigrationHook is a mock implementation of offboarding.MigrationHook
+type MockMigrationHook struct {
+	migrations []*offboarding.Migration
+	reference  string
+	err        error
+}
+
+func (m *MockMigrationHook) RequestMigration(ctx context.Context, migration *offboarding.Migration) (string, error) {
+	if m.err != nil {
+		return "", m.err
+	}
+	m.migrations = append(m.migrations, migration)
+	return m.reference, nil
+}
@@ -0,0 +1,141 @@
+package test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"apis/payments/services/openapi"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOpenAPI tests building OpenAPI documents from routes and Go types
+func TestOpenAPI(t *testing.T) {
+	info := openapi.Info{Title: "Test API", Version: "1.0.0"}
+
+	t.Run("should document every route below the prefix", func(t *testing.T) {
+		app := fiber.New()
+		api := app.Group("/api/v1", func(c *fiber.Ctx) error { return c.Next() })
+		widgets := api.Group("/widgets")
+		widgets.Post("/", func(c *fiber.Ctx) error { return nil })
+		widgets.Get("/:id", func(c *fiber.Ctx) error { return nil })
+		widgets.Delete("/:id", func(c *fiber.Ctx) error { return nil })
+		app.Get("/health", func(c *fiber.Ctx) error { return nil })
+
+		var routes []openapi.Route
+		for _, route := range app.GetRoutes(true) {
+			routes = append(routes, openapi.Route{Method: route.Method, Path: route.Path})
+		}
+		doc := openapi.NewBuilder(info, "/api/v1").Build(routes)
+
+		assert.Equal(t, openapi.Version, doc.OpenAPI)
+		assert.Equal(t, "/api/v1", doc.Servers[0].URL)
+		require.Len(t, doc.Paths, 2)
+		require.Contains(t, doc.Paths, "/widgets")
+		require.Contains(t, doc.Paths, "/widgets/{id}")
+		assert.NotContains(t, doc.Paths["/widgets/{id}"], "head")
+
+		get := doc.Paths["/widgets/{id}"]["get"]
+		require.NotNil(t, get)
+		assert.Equal(t, "getWidgetsById", get.OperationID)
+		assert.Equal(t, []string{"widgets"}, get.Tags)
+		require.Len(t, get.Parameters, 1)
+		assert.Equal(t, "id", get.Parameters[0].Name)
+		assert.Equal(t, "path", get.Parameters[0].In)
+		assert.Equal(t, "#/components/responses/NotFound", get.Responses["404"].Ref)
+		assert.Equal(t, "#/components/responses/Unauthorized", get.Responses["401"].Ref)
+		assert.NotContains(t, doc.Paths["/widgets"]["post"].Responses, "404")
+	})
+
+	t.Run("should describe bodies with component schemas", func(t *testing.T) {
+		builder := openapi.NewBuilder(info, "/api/v1")
+		builder.Describe("POST", "/widgets", openapi.Spec{
+			Summary:  "Create a widget",
+			Request:  OpenAPIWidgetRequest{},
+			Response: OpenAPIWidget{},
+			Status:   201,
+		})
+		builder.Describe("GET", "/widgets", openapi.Spec{Response: OpenAPIWidget{}, List: true})
+		doc := builder.Build([]openapi.Route{{Method: "POST", Path: "/api/v1/widgets/"}, {Method: "GET", Path: "/api/v1/widgets"}})
+
+		create := doc.Paths["/widgets"]["post"]
+		assert.Equal(t, "Create a widget", create.Summary)
+		assert.True(t, create.RequestBody.Required)
+		assert.Equal(t, "#/components/schemas/OpenAPIWidgetRequest", create.RequestBody.Content["application/json"].Schema.Ref)
+		assert.Equal(t, "#/components/schemas/OpenAPIWidget", create.Responses["201"].Content["application/json"].Schema.Ref)
+
+		request := doc.Components.Schemas["OpenAPIWidgetRequest"]
+		require.NotNil(t, request)
+		assert.Equal(t, []string{"color", "name"}, request.Required)
+		assert.Equal(t, []string{"red", "blue"}, request.Properties["color"].Enum)
+		assert.Equal(t, "object", request.Properties["metadata"].Type)
+		assert.Equal(t, "string", request.Properties["metadata"].AdditionalProperties.Type)
+		assert.NotContains(t, request.Properties, "secret")
+		assert.Contains(t, request.Properties, "audit_note", "embedded fields are flattened")
+
+		widget := doc.Components.Schemas["OpenAPIWidget"]
+		assert.Equal(t, "date-time", widget.Properties["created_at"].Format)
+		assert.Equal(t, "int64", widget.Properties["amount"].Format)
+		assert.Equal(t, "#/components/schemas/OpenAPIWidget", widget.Properties["parent"].Ref)
+		assert.Equal(t, "array", widget.Properties["tags"].Type)
+
+		list := doc.Paths["/widgets"]["get"]
+		page := list.Responses["200"].Content["application/json"].Schema
+		assert.Equal(t, "#/components/schemas/OpenAPIWidget", page.Properties["data"].Items.Ref)
+		assert.Contains(t, page.Properties, "next_cursor")
+		assert.Equal(t, "limit", list.Parameters[0].Name)
+	})
+
+	t.Run("should share the error schema across error responses", func(t *testing.T) {
+		doc := openapi.NewBuilder(info, "/api/v1").Build(nil)
+
+		errorSchema := doc.Components.Schemas[openapi.ErrorSchema]
+		require.NotNil(t, errorSchema)
+		assert.Equal(t, []string{"error", "display_message"}, errorSchema.Required)
+		for _, name := range []string{"BadRequest", "Unauthorized", "Forbidden", "NotFound", "TooManyRequests", "InternalError"} {
+			require.Contains(t, doc.Components.Responses, name)
+			assert.Equal(t, "#/components/schemas/Error", doc.Components.Responses[name].Content["application/json"].Schema.Ref)
+		}
+
+		encoded, err := json.Marshal(doc)
+		require.NoError(t, err)
+		assert.Contains(t, string(encoded), `"bearerAuth"`)
+	})
+
+	t.Run("should map Go types to JSON schema types", func(t *testing.T) {
+		builder := openapi.NewBuilder(info, "/api/v1")
+
+		assert.Equal(t, "boolean", builder.SchemaOf(reflect.TypeOf(true)).Type)
+		assert.Equal(t, "number", builder.SchemaOf(reflect.TypeOf(1.5)).Type)
+		assert.Equal(t, "byte", builder.SchemaOf(reflect.TypeOf([]byte{})).Format)
+		assert.True(t, builder.SchemaOf(reflect.TypeOf(new(string))).Nullable)
+		assert.Equal(t, &openapi.Schema{}, builder.SchemaOf(reflect.TypeOf(map[string]any{}).Elem()))
+	})
+}
+
+// OpenAPIWidgetAudit is embedded in OpenAPIWidgetRequest
+type OpenAPIWidgetAudit struct {
+	AuditNote string `json:"audit_note,omitempty"`
+}
+
+// OpenAPIWidgetRequest is a request body for TestOpenAPI
+type OpenAPIWidgetRequest struct {
+	OpenAPIWidgetAudit
+	Name     string            `json:"name" validate:"required"`
+	Color    string            `json:"color" validate:"required,oneof=red blue"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Secret   string            `json:"-"`
+}
+
+// OpenAPIWidget is a response body for TestOpenAPI
+type OpenAPIWidget struct {
+	ID        string         `json:"id"`
+	Amount    int64          `json:"amount"`
+	Tags      []string       `json:"tags"`
+	Parent    *OpenAPIWidget `json:"parent,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+}
@@ -0,0 +1,107 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"apis/payments/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGatewayPagination tests cursors flowing through provider list requests
+func TestGatewayPagination(t *testing.T) {
+	serve := func(respond func(query url.Values) any) (*httptest.Server, *url.Values) {
+		var received url.Values
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = r.URL.Query()
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(respond(received))
+		}))
+		t.Cleanup(server.Close)
+		return server, &received
+	}
+
+	t.Run("should continue paddle lists after the cursor and return the last ID as the next cursor", func(t *testing.T) {
+		server, received := serve(func(query url.Values) any {
+			return map[string]any{
+				"data": []map[string]any{
+					{"id": "txn_3", "status": "completed"},
+					{"id": "txn_2", "status": "completed"},
+				},
+				"meta": map[string]any{"pagination": map[string]any{"has_more": true, "estimated_total": 5}},
+			}
+		})
+		gateway, err := services.NewPaddleGateway(map[string]interface{}{
+			"api_key":     "pdl_sdbx_test",
+			"environment": "sandbox",
+			"base_url":    server.URL,
+		})
+		require.NoError(t, err)
+
+		list, err := gateway.ListCharges(context.Background(), services.ListChargesRequest{Limit: 2, Cursor: "txn_4"})
+		require.NoError(t, err)
+
+		assert.Equal(t, "2", received.Get("per_page"))
+		assert.Equal(t, "txn_4", received.Get("after"))
+		assert.Len(t, list.Charges, 2)
+		assert.True(t, list.HasMore)
+		assert.Equal(t, "txn_2", list.NextCursor)
+	})
+
+	t.Run("should not return a next cursor from the last paddle page", func(t *testing.T) {
+		server, _ := serve(func(query url.Values) any {
+			return map[string]any{
+				"data": []map[string]any{{"id": "ctm_1", "status": "active"}},
+				"meta": map[string]any{"pagination": map[string]any{"has_more": false, "estimated_total": 1}},
+			}
+		})
+		gateway, err := services.NewPaddleGateway(map[string]interface{}{
+			"api_key":     "pdl_sdbx_test",
+			"environment": "sandbox",
+			"base_url":    server.URL,
+		})
+		require.NoError(t, err)
+
+		list, err := gateway.ListCustomers(context.Background(), services.ListCustomersRequest{Cursor: "ctm_2"})
+		require.NoError(t, err)
+
+		assert.False(t, list.HasMore)
+		assert.Empty(t, list.NextCursor)
+	})
+
+	t.Run("should pass square's opaque cursor through without cutting filtered pages short", func(t *testing.T) {
+		server, received := serve(func(query url.Values) any {
+			return map[string]any{
+				"refunds": []map[string]any{
+					{"id": "r_1", "payment_id": "p_1", "status": "COMPLETED"},
+					{"id": "r_2", "payment_id": "p_2", "status": "COMPLETED"},
+					{"id": "r_3", "payment_id": "p_1", "status": "COMPLETED"},
+				},
+				"cursor": "opaque_next",
+			}
+		})
+		gateway, err := services.NewSquareGateway(map[string]interface{}{
+			"application_id": "sq0idp-test",
+			"access_token":   "EAAA_test",
+			"environment":    "sandbox",
+			"base_url":       server.URL,
+		})
+		require.NoError(t, err)
+
+		list, err := gateway.ListRefunds(context.Background(), services.ListRefundsRequest{Limit: 1, ChargeID: "p_1", Cursor: "opaque_prev"})
+		require.NoError(t, err)
+
+		assert.Equal(t, "opaque_prev", received.Get("cursor"))
+		assert.Equal(t, "100", received.Get("limit"))
+		require.Len(t, list.Refunds, 2)
+		assert.Equal(t, "r_3", list.Refunds[1].ID)
+		assert.True(t, list.HasMore)
+		assert.Equal(t, "opaque_next", list.NextCursor)
+	})
+}
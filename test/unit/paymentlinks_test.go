@@ -0,0 +1,250 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"apis/payments/services/events"
+	"apis/payments/services/paymentlinks"
+	"apis/payments/services/stripe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPaymentLinks tests creating payment links, expiring them and counting conversions
+func TestPaymentLinks(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func() (*paymentlinks.Service, *MockPaymentLinkStore, *MockPaymentLinkProvider, *MockEventPublisher) {
+		store := &MockPaymentLinkStore{links: make(map[string]*paymentlinks.PaymentLink), conversions: make(map[string]*paymentlinks.Conversion)}
+		provider := &MockPaymentLinkProvider{}
+		publisher := &MockEventPublisher{}
+		source, _ := events.NewSource("/payments")
+		service := paymentlinks.NewService(store, provider, events.NewEmitter(source, publisher), &paymentlinks.Config{ExpiryInterval: time.Minute})
+		return service, store, provider, publisher
+	}
+
+	createRequest := func() *paymentlinks.CreateRequest {
+		return &paymentlinks.CreateRequest{Amount: 2500, Currency: "usd", ProductName: "Workshop ticket"}
+	}
+
+	conversion := func(sessionID string) *paymentlinks.Conversion {
+		return &paymentlinks.Conversion{
+			SessionID:     sessionID,
+			PaymentLinkID: "plink_1",
+			AmountTotal:   2500,
+			Currency:      "usd",
+			CompletedAt:   time.Now(),
+		}
+	}
+
+	t.Run("should create the link at the provider and store it", func(t *testing.T) {
+		service, store, provider, _ := setup()
+		request := createRequest()
+		request.MaxUses = 10
+
+		link, err := service.Create(ctx, request)
+		require.NoError(t, err)
+
+		assert.Equal(t, "plink_1", link.ID)
+		assert.Equal(t, "price_1", link.PriceID)
+		assert.True(t, link.Active)
+		assert.Equal(t, int64(10), provider.created[0].MaxUses)
+		assert.Equal(t, int64(2500), store.links["plink_1"].Amount)
+	})
+
+	t.Run("should reject an expiry in the past", func(t *testing.T) {
+		service, store, provider, _ := setup()
+		request := createRequest()
+		past := time.Now().Add(-time.Hour)
+		request.ExpiresAt = &past
+
+		_, err := service.Create(ctx, request)
+		assert.ErrorIs(t, err, paymentlinks.ErrExpiryInPast)
+		assert.Empty(t, provider.created)
+		assert.Empty(t, store.links)
+	})
+
+	t.Run("should deactivate expired links and emit an event", func(t *testing.T) {
+		service, store, provider, publisher := setup()
+		request := createRequest()
+		expiresAt := time.Now().Add(time.Hour)
+		request.ExpiresAt = &expiresAt
+		_, err := service.Create(ctx, request)
+		require.NoError(t, err)
+
+		count, err := service.ExpireDue(ctx, time.Now())
+		require.NoError(t, err)
+		assert.Zero(t, count)
+
+		count, err = service.ExpireDue(ctx, expiresAt.Add(time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+		assert.False(t, store.links["plink_1"].Active)
+		assert.Equal(t, []string{"plink_1"}, provider.deactivated)
+		require.Len(t, publisher.events, 1)
+		assert.Equal(t, paymentlinks.EventExpired, publisher.events[0].Type)
+	})
+
+	t.Run("should count each checkout session once", func(t *testing.T) {
+		service, store, _, publisher := setup()
+		_, err := service.Create(ctx, createRequest())
+		require.NoError(t, err)
+
+		require.NoError(t, service.RecordConversion(ctx, conversion("cs_1")))
+		require.NoError(t, service.RecordConversion(ctx, conversion("cs_1")))
+		require.NoError(t, service.RecordConversion(ctx, conversion("cs_2")))
+
+		assert.Equal(t, int64(2), store.links["plink_1"].Conversions)
+		assert.Equal(t, int64(5000), store.links["plink_1"].AmountCollected)
+		require.Len(t, publisher.events, 2)
+		assert.Equal(t, paymentlinks.EventConverted, publisher.events[0].Type)
+	})
+
+	t.Run("should mark a link inactive once its max uses are reached", func(t *testing.T) {
+		service, store, _, _ := setup()
+		request := createRequest()
+		request.MaxUses = 1
+		_, err := service.Create(ctx, request)
+		require.NoError(t, err)
+
+		require.NoError(t, service.RecordConversion(ctx, conversion("cs_1")))
+
+		assert.False(t, store.links["plink_1"].Active)
+		assert.NotNil(t, store.links["plink_1"].DeactivatedAt)
+	})
+
+	t.Run("should ignore checkouts through unknown links", func(t *testing.T) {
+		service, store, _, publisher := setup()
+
+		require.NoError(t, service.RecordConversion(ctx, conversion("cs_1")))
+		assert.Empty(t, store.conversions)
+		assert.Empty(t, publisher.events)
+	})
+
+	t.Run("should deactivate a link only once", func(t *testing.T) {
+		service, _, provider, _ := setup()
+		_, err := service.Create(ctx, createRequest())
+		require.NoError(t, err)
+
+		link, err := service.Deactivate(ctx, "plink_1")
+		require.NoError(t, err)
+		assert.False(t, link.Active)
+
+		_, err = service.Deactivate(ctx, "plink_1")
+		require.NoError(t, err)
+		assert.Len(t, provider.deactivated, 1)
+	})
+}
+
+// MockPaymentLinkStore keeps payment links and conversions in memory
+type MockPaymentLinkStore struct {
+	links       map[string]*paymentlinks.PaymentLink
+	conversions map[string]*paymentlinks.Conversion
+}
+
+func (m *MockPaymentLinkStore) CreatePaymentLink(ctx context.Context, link *paymentlinks.PaymentLink) (*paymentlinks.PaymentLink, error) {
+	stored := *link
+	stored.CreatedAt = time.Now()
+	m.links[link.ID] = &stored
+	return m.copy(&stored), nil
+}
+
+func (m *MockPaymentLinkStore) GetPaymentLink(ctx context.Context, linkID string) (*paymentlinks.PaymentLink, error) {
+	link, ok := m.links[linkID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return m.copy(link), nil
+}
+
+func (m *MockPaymentLinkStore) ListPaymentLinks(ctx context.Context, filter paymentlinks.Filter) ([]*paymentlinks.PaymentLink, error) {
+	var result []*paymentlinks.PaymentLink
+	for _, link := range m.links {
+		if !filter.ActiveOnly || link.Active {
+			result = append(result, m.copy(link))
+		}
+	}
+	return result, nil
+}
+
+func (m *MockPaymentLinkStore) DeactivatePaymentLink(ctx context.Context, linkID string, at time.Time) (*paymentlinks.PaymentLink, error) {
+	link, ok := m.links[linkID]
+	if !ok || !link.Active {
+		return nil, sql.ErrNoRows
+	}
+	link.Active = false
+	link.DeactivatedAt = &at
+	return m.copy(link), nil
+}
+
+func (m *MockPaymentLinkStore) ListExpiredPaymentLinks(ctx context.Context, now time.Time) ([]*paymentlinks.PaymentLink, error) {
+	var result []*paymentlinks.PaymentLink
+	for _, link := range m.links {
+		if link.Active && link.ExpiresAt != nil && !link.ExpiresAt.After(now) {
+			result = append(result, m.copy(link))
+		}
+	}
+	return result, nil
+}
+
+func (m *MockPaymentLinkStore) InsertPaymentLinkConversion(ctx context.Context, conversion *paymentlinks.Conversion) (bool, error) {
+	if _, ok := m.conversions[conversion.SessionID]; ok {
+		return false, nil
+	}
+	m.conversions[conversion.SessionID] = conversion
+	return true, nil
+}
+
+func (m *MockPaymentLinkStore) AddPaymentLinkConversion(ctx context.Context, linkID string, amount int64) (*paymentlinks.PaymentLink, error) {
+	link, ok := m.links[linkID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	link.Conversions++
+	link.AmountCollected += amount
+	return m.copy(link), nil
+}
+
+func (m *MockPaymentLinkStore) ListPaymentLinkConversions(ctx context.Context, linkID string, limit int) ([]*paymentlinks.Conversion, error) {
+	var result []*paymentlinks.Conversion
+	for _, conversion := range m.conversions {
+		if conversion.PaymentLinkID == linkID {
+			result = append(result, conversion)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockPaymentLinkStore) copy(link *paymentlinks.PaymentLink) *paymentlinks.PaymentLink {
+	c := *link
+	return &c
+}
+
+// MockPaymentLinkProvider records payment links created and deactivated at the provider
+type MockPaymentLinkProvider struct {
+	created     []*stripe.PaymentLinkRequest
+	deactivated []string
+}
+
+func (m *MockPaymentLinkProvider) CreatePaymentLink(ctx context.Context, request *stripe.PaymentLinkRequest) (*stripe.PaymentLink, error) {
+	m.created = append(m.created, request)
+	n := len(m.created)
+	return &stripe.PaymentLink{
+		ID:       fmt.Sprintf("plink_%d", n),
+		URL:      fmt.Sprintf("https://buy.stripe.com/test_%d", n),
+		Active:   true,
+		Currency: request.Currency,
+		PriceID:  fmt.Sprintf("price_%d", n),
+		MaxUses:  request.MaxUses,
+	}, nil
+}
+
+func (m *MockPaymentLinkProvider) DeactivatePaymentLink(ctx context.Context, linkID string) (*stripe.PaymentLink, error) {
+	m.deactivated = append(m.deactivated, linkID)
+	return &stripe.PaymentLink{ID: linkID, Active: false}, nil
+}
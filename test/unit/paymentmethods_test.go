@@ -0,0 +1,308 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"apis/payments/services/paymentmethods"
+	"apis/payments/services/stripe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// TestPaymentMethods tests customers' default and backup payment methods and
+// falling back to the backups when charges and subscriptions are declined
+func TestPaymentMethods(t *testing.T) {
+	ctx := context.Background()
+	declined := func(code stripego.DeclineCode) error {
+		return &stripego.Error{Type: stripego.ErrorTypeCard, Code: stripego.ErrorCodeCardDeclined, DeclineCode: code}
+	}
+	newService := func(provider *MockPaymentMethodProvider, charges *MockFallbackCharger, invoices *MockFallbackInvoicePayer) (*paymentmethods.Service, *MockPaymentMethodPreferenceStore) {
+		store := NewMockPaymentMethodPreferenceStore()
+		return paymentmethods.NewService(store, provider, charges, invoices, &MockFallbackSubscriptions{}), store
+	}
+
+	t.Run("should fall back to the provider's default without a saved preference", func(t *testing.T) {
+		provider := NewMockPaymentMethodProvider("cus_1", "pm_a")
+		provider.defaults["cus_1"] = "pm_a"
+		service, _ := newService(provider, &MockFallbackCharger{}, &MockFallbackInvoicePayer{})
+
+		preference, err := service.Get(ctx, "tenant_1", "cus_1")
+		require.NoError(t, err)
+
+		assert.Equal(t, "pm_a", preference.DefaultPaymentMethod)
+		assert.Empty(t, preference.BackupPaymentMethods)
+	})
+
+	t.Run("should sync a new default and keep the previous one as the first backup", func(t *testing.T) {
+		provider := NewMockPaymentMethodProvider("cus_1", "pm_a", "pm_b", "pm_c")
+		service, store := newService(provider, &MockFallbackCharger{}, &MockFallbackInvoicePayer{})
+
+		_, err := service.SetDefault(ctx, "tenant_1", "cus_1", "pm_a")
+		require.NoError(t, err)
+		_, err = service.SetBackups(ctx, "tenant_1", "cus_1", []string{"pm_c"})
+		require.NoError(t, err)
+
+		preference, err := service.SetDefault(ctx, "tenant_1", "cus_1", "pm_b")
+		require.NoError(t, err)
+
+		assert.Equal(t, "pm_b", preference.DefaultPaymentMethod)
+		assert.Equal(t, []string{"pm_a", "pm_c"}, preference.BackupPaymentMethods)
+		assert.Equal(t, "pm_b", provider.defaults["cus_1"])
+		assert.Equal(t, "pm_b", store.preferences["tenant_1/cus_1"].DefaultPaymentMethod)
+	})
+
+	t.Run("should reject payment methods of other customers", func(t *testing.T) {
+		provider := NewMockPaymentMethodProvider("cus_1", "pm_a")
+		provider.methods["pm_other"] = "cus_2"
+		service, store := newService(provider, &MockFallbackCharger{}, &MockFallbackInvoicePayer{})
+
+		_, err := service.SetDefault(ctx, "tenant_1", "cus_1", "pm_other")
+		assert.ErrorIs(t, err, paymentmethods.ErrNotAttached)
+		assert.Empty(t, provider.defaults)
+		assert.Empty(t, store.preferences)
+
+		_, err = service.SetDefault(ctx, "tenant_1", "cus_1", "pm_a")
+		require.NoError(t, err)
+		_, err = service.SetBackups(ctx, "tenant_1", "cus_1", []string{"pm_other"})
+		assert.ErrorIs(t, err, paymentmethods.ErrNotAttached)
+	})
+
+	t.Run("should reject invalid backup orders", func(t *testing.T) {
+		provider := NewMockPaymentMethodProvider("cus_1", "pm_a", "pm_b", "pm_c", "pm_d", "pm_e", "pm_f", "pm_g")
+		service, _ := newService(provider, &MockFallbackCharger{}, &MockFallbackInvoicePayer{})
+
+		_, err := service.SetBackups(ctx, "tenant_1", "cus_1", []string{"pm_b"})
+		assert.ErrorIs(t, err, paymentmethods.ErrInvalidPreference, "backups need a default")
+
+		_, err = service.SetDefault(ctx, "tenant_1", "cus_1", "pm_a")
+		require.NoError(t, err)
+
+		for name, backups := range map[string][]string{
+			"default":   {"pm_b", "pm_a"},
+			"duplicate": {"pm_b", "pm_c", "pm_b"},
+			"too many":  {"pm_b", "pm_c", "pm_d", "pm_e", "pm_f", "pm_g"},
+		} {
+			_, err := service.SetBackups(ctx, "tenant_1", "cus_1", backups)
+			assert.ErrorIs(t, err, paymentmethods.ErrInvalidPreference, name)
+		}
+	})
+
+	t.Run("should promote the first backup when the default is detached", func(t *testing.T) {
+		provider := NewMockPaymentMethodProvider("cus_1", "pm_a", "pm_b", "pm_c")
+		service, _ := newService(provider, &MockFallbackCharger{}, &MockFallbackInvoicePayer{})
+
+		_, err := service.SetDefault(ctx, "tenant_1", "cus_1", "pm_a")
+		require.NoError(t, err)
+		_, err = service.SetBackups(ctx, "tenant_1", "cus_1", []string{"pm_b", "pm_c"})
+		require.NoError(t, err)
+
+		require.NoError(t, service.Forget(ctx, "tenant_1", "cus_1", "pm_c"))
+		require.NoError(t, service.Forget(ctx, "tenant_1", "cus_1", "pm_a"))
+
+		preference, err := service.Get(ctx, "tenant_1", "cus_1")
+		require.NoError(t, err)
+		assert.Equal(t, "pm_b", preference.DefaultPaymentMethod)
+		assert.Empty(t, preference.BackupPaymentMethods)
+		assert.Equal(t, "pm_b", provider.defaults["cus_1"])
+	})
+
+	t.Run("should charge the default with backups only when no payment method is given", func(t *testing.T) {
+		provider := NewMockPaymentMethodProvider("cus_1", "pm_a", "pm_b")
+		service, _ := newService(provider, &MockFallbackCharger{}, &MockFallbackInvoicePayer{})
+		_, err := service.SetDefault(ctx, "tenant_1", "cus_1", "pm_a")
+		require.NoError(t, err)
+		_, err = service.SetBackups(ctx, "tenant_1", "cus_1", []string{"pm_b"})
+		require.NoError(t, err)
+
+		request := &stripe.ChargeRequest{CustomerID: "cus_1", Amount: 1000, Currency: "usd"}
+		require.NoError(t, service.ApplyPreference(ctx, "tenant_1", request))
+		assert.Equal(t, "pm_a", request.PaymentMethod)
+		assert.Equal(t, []string{"pm_b"}, request.FallbackPaymentMethods)
+
+		explicit := &stripe.ChargeRequest{CustomerID: "cus_1", PaymentMethod: "pm_card", Amount: 1000, Currency: "usd"}
+		require.NoError(t, service.ApplyPreference(ctx, "tenant_1", explicit))
+		assert.Equal(t, "pm_card", explicit.PaymentMethod)
+		assert.Empty(t, explicit.FallbackPaymentMethods)
+	})
+
+	t.Run("should retry declined charges with each backup in order", func(t *testing.T) {
+		charges := &MockFallbackCharger{failures: map[string]error{
+			"pm_a": declined(stripego.DeclineCodeInsufficientFunds),
+			"pm_b": declined(stripego.DeclineCodeExpiredCard),
+		}}
+		service, _ := newService(NewMockPaymentMethodProvider("cus_1"), charges, &MockFallbackInvoicePayer{})
+
+		charge, err := service.Charge(ctx, &stripe.ChargeRequest{
+			CustomerID:             "cus_1",
+			PaymentMethod:          "pm_a",
+			FallbackPaymentMethods: []string{"pm_b", "pm_c", "pm_d"},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, "ch_pm_c", charge.ID)
+		assert.Equal(t, []string{"pm_a", "pm_b", "pm_c"}, charges.attempts)
+	})
+
+	t.Run("should not fall back after fraud declines or other errors", func(t *testing.T) {
+		for name, failure := range map[string]error{
+			"fraudulent": declined(stripego.DeclineCodeFraudulent),
+			"api error":  errors.New("connection reset"),
+		} {
+			charges := &MockFallbackCharger{failures: map[string]error{"pm_a": failure}}
+			service, _ := newService(NewMockPaymentMethodProvider("cus_1"), charges, &MockFallbackInvoicePayer{})
+
+			_, err := service.Charge(ctx, &stripe.ChargeRequest{
+				CustomerID:             "cus_1",
+				PaymentMethod:          "pm_a",
+				FallbackPaymentMethods: []string{"pm_b"},
+			})
+			assert.ErrorIs(t, err, failure, name)
+			assert.Equal(t, []string{"pm_a"}, charges.attempts, name)
+		}
+	})
+
+	t.Run("should return the last decline when every payment method is declined", func(t *testing.T) {
+		last := declined(stripego.DeclineCodeGenericDecline)
+		charges := &MockFallbackCharger{failures: map[string]error{
+			"pm_a": declined(stripego.DeclineCodeInsufficientFunds),
+			"pm_b": last,
+		}}
+		service, _ := newService(NewMockPaymentMethodProvider("cus_1"), charges, &MockFallbackInvoicePayer{})
+
+		_, err := service.Charge(ctx, &stripe.ChargeRequest{
+			CustomerID:             "cus_1",
+			PaymentMethod:          "pm_a",
+			FallbackPaymentMethods: []string{"pm_b"},
+		})
+		assert.ErrorIs(t, err, last)
+	})
+
+	t.Run("should pay incomplete subscriptions' first invoice with backups", func(t *testing.T) {
+		provider := NewMockPaymentMethodProvider("cus_1", "pm_a", "pm_b", "pm_c")
+		invoices := &MockFallbackInvoicePayer{failures: map[string]error{
+			"pm_b": fmt.Errorf("failed to pay invoice: %w", declined(stripego.DeclineCodeInsufficientFunds)),
+		}}
+		service, _ := newService(provider, &MockFallbackCharger{}, invoices)
+		_, err := service.SetDefault(ctx, "tenant_1", "cus_1", "pm_a")
+		require.NoError(t, err)
+		_, err = service.SetBackups(ctx, "tenant_1", "cus_1", []string{"pm_b", "pm_c"})
+		require.NoError(t, err)
+
+		subscription, err := service.RecoverSubscription(ctx, "tenant_1", &stripe.Subscription{
+			ID: "sub_1", CustomerID: "cus_1", Status: "incomplete", LatestInvoiceID: "in_1",
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, "active", subscription.Status)
+		assert.Equal(t, []string{"pm_b", "pm_c"}, invoices.attempts)
+
+		active := &stripe.Subscription{ID: "sub_2", CustomerID: "cus_1", Status: "active", LatestInvoiceID: "in_2"}
+		unchanged, err := service.RecoverSubscription(ctx, "tenant_1", active)
+		require.NoError(t, err)
+		assert.Same(t, active, unchanged)
+		assert.Len(t, invoices.attempts, 2)
+	})
+}
+
+// MockPaymentMethodPreferenceStore keeps payment method preferences in memory
+type MockPaymentMethodPreferenceStore struct {
+	mu          sync.Mutex
+	preferences map[string]*paymentmethods.Preference
+}
+
+func NewMockPaymentMethodPreferenceStore() *MockPaymentMethodPreferenceStore {
+	return &MockPaymentMethodPreferenceStore{preferences: make(map[string]*paymentmethods.Preference)}
+}
+
+func (m *MockPaymentMethodPreferenceStore) GetPaymentMethodPreference(ctx context.Context, tenantID, customerID string) (*paymentmethods.Preference, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	preference, ok := m.preferences[tenantID+"/"+customerID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *preference
+	copied.BackupPaymentMethods = append([]string{}, preference.BackupPaymentMethods...)
+	return &copied, nil
+}
+
+func (m *MockPaymentMethodPreferenceStore) UpsertPaymentMethodPreference(ctx context.Context, preference *paymentmethods.Preference) (*paymentmethods.Preference, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	saved := *preference
+	m.preferences[preference.TenantID+"/"+preference.CustomerID] = &saved
+	return preference, nil
+}
+
+// MockPaymentMethodProvider holds customers' payment methods and defaults
+type MockPaymentMethodProvider struct {
+	methods  map[string]string
+	defaults map[string]string
+}
+
+func NewMockPaymentMethodProvider(customerID string, paymentMethodIDs ...string) *MockPaymentMethodProvider {
+	provider := &MockPaymentMethodProvider{methods: make(map[string]string), defaults: make(map[string]string)}
+	for _, id := range paymentMethodIDs {
+		provider.methods[id] = customerID
+	}
+	return provider
+}
+
+func (m *MockPaymentMethodProvider) GetCustomer(ctx context.Context, customerID string) (*stripe.Customer, error) {
+	return &stripe.Customer{ID: customerID, DefaultPaymentMethod: m.defaults[customerID]}, nil
+}
+
+func (m *MockPaymentMethodProvider) GetPaymentMethod(ctx context.Context, paymentMethodID string) (*stripe.PaymentMethod, error) {
+	customerID, ok := m.methods[paymentMethodID]
+	if !ok {
+		return nil, fmt.Errorf("no such payment method: %s", paymentMethodID)
+	}
+	return &stripe.PaymentMethod{ID: paymentMethodID, Customer: customerID}, nil
+}
+
+func (m *MockPaymentMethodProvider) SetDefaultPaymentMethod(ctx context.Context, customerID, paymentMethodID string) error {
+	m.defaults[customerID] = paymentMethodID
+	return nil
+}
+
+// MockFallbackCharger records charge attempts and declines some payment methods
+type MockFallbackCharger struct {
+	failures map[string]error
+	attempts []string
+}
+
+func (m *MockFallbackCharger) CreateCharge(ctx context.Context, request *stripe.ChargeRequest) (*stripe.Charge, error) {
+	m.attempts = append(m.attempts, request.PaymentMethod)
+	if err := m.failures[request.PaymentMethod]; err != nil {
+		return nil, err
+	}
+	return &stripe.Charge{ID: "ch_" + request.PaymentMethod, Status: "succeeded"}, nil
+}
+
+// MockFallbackInvoicePayer records invoice payments and declines some payment methods
+type MockFallbackInvoicePayer struct {
+	failures map[string]error
+	attempts []string
+}
+
+func (m *MockFallbackInvoicePayer) PayInvoice(ctx context.Context, invoiceID, paymentMethodID string) (*stripe.Invoice, error) {
+	m.attempts = append(m.attempts, paymentMethodID)
+	if err := m.failures[paymentMethodID]; err != nil {
+		return nil, err
+	}
+	return &stripe.Invoice{ID: invoiceID, Status: "paid"}, nil
+}
+
+// MockFallbackSubscriptions returns subscriptions as active once their invoice is paid
+type MockFallbackSubscriptions struct{}
+
+func (m *MockFallbackSubscriptions) GetSubscription(ctx context.Context, subscriptionID string) (*stripe.Subscription, error) {
+	return &stripe.Subscription{ID: subscriptionID, Status: "active"}, nil
+}
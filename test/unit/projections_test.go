@@ -0,0 +1,191 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"apis/payments/services/projections"
+	"apis/payments/services/stripe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestChargeProjection tests maintaining denormalized charge list rows
+func TestChargeProjection(t *testing.T) {
+	setup := func() (*projections.Service, *MockProjectionStore, *MockProjectionSources) {
+		store := NewMockProjectionStore()
+		sources := &MockProjectionSources{
+			charges: map[string]*stripe.Charge{
+				"ch_1": {
+					ID:         "ch_1",
+					Amount:     2000,
+					Currency:   "usd",
+					Status:     "succeeded",
+					CustomerID: "cus_1",
+					InvoiceID:  "in_1",
+					Metadata:   map[string]string{"tenant_id": "acme"},
+					Created:    1700000000,
+				},
+			},
+			customers: map[string]*stripe.Customer{
+				"cus_1": {ID: "cus_1", Email: "jane@example.com", Name: "Jane"},
+			},
+			plans: map[string]string{"in_1": "Pro Monthly"},
+		}
+		service := projections.NewService(store, sources, sources, sources, sources)
+		return service, store, sources
+	}
+
+	t.Run("should project a charge with its customer and plan", func(t *testing.T) {
+		service, store, _ := setup()
+
+		require.NoError(t, service.ProjectCharge(context.Background(), "ch_1"))
+
+		row := store.rows["ch_1"]
+		require.NotNil(t, row)
+		assert.Equal(t, "acme", row.TenantID)
+		assert.Equal(t, "jane@example.com", row.CustomerEmail)
+		assert.Equal(t, "Jane", row.CustomerName)
+		assert.Equal(t, "Pro Monthly", row.PlanName)
+		assert.Equal(t, int64(2000), row.Amount)
+		assert.Equal(t, time.Unix(1700000000, 0), row.ChargeCreated)
+	})
+
+	t.Run("should still project a charge whose customer was deleted", func(t *testing.T) {
+		service, store, sources := setup()
+		delete(sources.customers, "cus_1")
+
+		require.NoError(t, service.ProjectCharge(context.Background(), "ch_1"))
+		assert.Empty(t, store.rows["ch_1"].CustomerEmail)
+	})
+
+	t.Run("should record the latest refund", func(t *testing.T) {
+		service, store, sources := setup()
+		require.NoError(t, service.ProjectCharge(context.Background(), "ch_1"))
+
+		now := time.Now()
+		sources.refunds = []*stripe.Refund{
+			{ID: "re_old", ChargeID: "ch_1", Status: "succeeded", CreatedAt: now.Add(-time.Hour)},
+			{ID: "re_new", ChargeID: "ch_1", Status: "pending", CreatedAt: now},
+		}
+
+		require.NoError(t, service.ProjectRefund(context.Background(), "ch_1"))
+		assert.Equal(t, "re_new", store.rows["ch_1"].LastRefundID)
+		assert.Equal(t, "pending", store.rows["ch_1"].LastRefundStatus)
+	})
+
+	t.Run("should update customer details on existing rows", func(t *testing.T) {
+		service, store, _ := setup()
+		require.NoError(t, service.ProjectCharge(context.Background(), "ch_1"))
+
+		require.NoError(t, service.ProjectCustomer(context.Background(), "cus_1", "jane@new.example.com", "Jane D"))
+		assert.Equal(t, "jane@new.example.com", store.rows["ch_1"].CustomerEmail)
+		assert.Equal(t, "Jane D", store.rows["ch_1"].CustomerName)
+	})
+
+	t.Run("should rebuild every charge", func(t *testing.T) {
+		service, store, sources := setup()
+		sources.charges["ch_2"] = &stripe.Charge{ID: "ch_2", Amount: 500, Currency: "usd", Status: "failed"}
+
+		projected, err := service.Rebuild(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 2, projected)
+		assert.Len(t, store.rows, 2)
+		assert.Equal(t, projections.DefaultTenantID, store.rows["ch_2"].TenantID)
+	})
+
+	t.Run("should clamp list limits", func(t *testing.T) {
+		service, store, _ := setup()
+
+		_, err := service.ListCharges(context.Background(), projections.ChargeFilter{Limit: 10000})
+		require.NoError(t, err)
+		assert.Equal(t, 500, store.lastFilter.Limit)
+		assert.Equal(t, projections.DefaultTenantID, store.lastFilter.TenantID)
+	})
+}
+
+// MockProjectionStore keeps charge list rows in memory
+type MockProjectionStore struct {
+	rows       map[string]*projections.ChargeRow
+	lastFilter projections.ChargeFilter
+}
+
+// NewMockProjectionStore creates an empty projection store
+func NewMockProjectionStore() *MockProjectionStore {
+	return &MockProjectionStore{rows: make(map[string]*projections.ChargeRow)}
+}
+
+func (m *MockProjectionStore) UpsertChargeRow(ctx context.Context, row *projections.ChargeRow) error {
+	if existing, ok := m.rows[row.ChargeID]; ok {
+		row.LastRefundID = existing.LastRefundID
+		row.LastRefundStatus = existing.LastRefundStatus
+	}
+	m.rows[row.ChargeID] = row
+	return nil
+}
+
+func (m *MockProjectionStore) UpdateChargeRowRefund(ctx context.Context, chargeID, refundID, refundStatus string) error {
+	if row, ok := m.rows[chargeID]; ok {
+		row.LastRefundID = refundID
+		row.LastRefundStatus = refundStatus
+	}
+	return nil
+}
+
+func (m *MockProjectionStore) UpdateChargeRowsCustomer(ctx context.Context, customerID, email, name string) error {
+	for _, row := range m.rows {
+		if row.CustomerID == customerID {
+			row.CustomerEmail = email
+			row.CustomerName = name
+		}
+	}
+	return nil
+}
+
+func (m *MockProjectionStore) ListChargeRows(ctx context.Context, filter projections.ChargeFilter) ([]*projections.ChargeRow, error) {
+	m.lastFilter = filter
+	return nil, nil
+}
+
+// MockProjectionSources serves charges, customers, refunds and plans from memory
+type MockProjectionSources struct {
+	charges   map[string]*stripe.Charge
+	customers map[string]*stripe.Customer
+	refunds   []*stripe.Refund
+	plans     map[string]string
+}
+
+func (m *MockProjectionSources) GetCharge(ctx context.Context, chargeID string) (*stripe.Charge, error) {
+	charge, ok := m.charges[chargeID]
+	if !ok {
+		return nil, errors.New("charge not found")
+	}
+	return charge, nil
+}
+
+func (m *MockProjectionSources) ListCharges(ctx context.Context, customerID string, page stripe.Page) ([]*stripe.Charge, error) {
+	var charges []*stripe.Charge
+	for _, charge := range m.charges {
+		charges = append(charges, charge)
+	}
+	return charges, nil
+}
+
+func (m *MockProjectionSources) GetCustomer(ctx context.Context, customerID string) (*stripe.Customer, error) {
+	customer, ok := m.customers[customerID]
+	if !ok {
+		return nil, errors.New("customer not found")
+	}
+	return customer, nil
+}
+
+func (m *MockProjectionSources) ListRefunds(ctx context.Context, chargeID string, page stripe.Page) ([]*stripe.Refund, error) {
+	return m.refunds, nil
+}
+
+func (m *MockProjectionSources) InvoicePlanName(ctx context.Context, invoiceID string) (string, error) {
+	return m.plans[invoiceID], nil
+}
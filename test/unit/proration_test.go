@@ -0,0 +1,148 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"apis/payments/services/stripe"
+	"apis/payments/services/subscriptions"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestProration tests previewing and applying prorated plan and quantity changes
+func TestProration(t *testing.T) {
+	ctx := context.Background()
+	periodStart := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	periodEnd := time.Date(2026, 10, 31, 0, 0, 0, 0, time.UTC)
+	halfway := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+
+	basic := &stripe.SubscriptionPlan{ID: "price_basic", Amount: 1000, Currency: "usd", Interval: "month", IntervalCount: 1}
+	pro := &stripe.SubscriptionPlan{ID: "price_pro", Amount: 3000, Currency: "usd", Interval: "month", IntervalCount: 1}
+	annual := &stripe.SubscriptionPlan{ID: "price_annual", Amount: 30000, Currency: "usd", Interval: "year", IntervalCount: 1}
+
+	subscription := func() *stripe.Subscription {
+		return &stripe.Subscription{
+			ID:                 "sub_1",
+			PriceID:            basic.ID,
+			Quantity:           1,
+			Status:             "active",
+			CurrentPeriodStart: periodStart.Unix(),
+			CurrentPeriodEnd:   periodEnd.Unix(),
+		}
+	}
+	setup := func() (*subscriptions.Service, *MockSubscriptionProvider) {
+		provider := NewMockSubscriptionProvider()
+		provider.subscriptions["sub_1"] = subscription()
+		for _, plan := range []*stripe.SubscriptionPlan{basic, pro, annual} {
+			provider.plans[plan.ID] = plan
+		}
+		return subscriptions.NewService(provider, nil), provider
+	}
+
+	t.Run("should credit unused time and charge the rest of the period", func(t *testing.T) {
+		preview, err := subscriptions.Prorate(subscription(), basic, pro, 0, halfway)
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(-500), preview.Credit)
+		assert.Equal(t, int64(1500), preview.Charge)
+		assert.Equal(t, int64(1000), preview.ProratedAmount)
+		assert.Equal(t, int64(4000), preview.NextInvoiceTotal)
+		assert.Equal(t, periodEnd.Unix(), preview.NextInvoiceAt)
+		assert.Equal(t, int64(1), preview.Quantity)
+		assert.Equal(t, stripe.ProrationSourceCalculated, preview.Source)
+	})
+
+	t.Run("should prorate quantity changes", func(t *testing.T) {
+		preview, err := subscriptions.Prorate(subscription(), basic, basic, 3, halfway)
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(-500), preview.Credit)
+		assert.Equal(t, int64(1500), preview.Charge)
+		assert.Equal(t, int64(4000), preview.NextInvoiceTotal)
+	})
+
+	t.Run("should charge a new billing interval in full straight away", func(t *testing.T) {
+		preview, err := subscriptions.Prorate(subscription(), basic, annual, 0, halfway)
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(-500), preview.Credit)
+		assert.Equal(t, int64(30000), preview.Charge)
+		assert.Equal(t, int64(29500), preview.NextInvoiceTotal)
+		assert.Equal(t, halfway.Unix(), preview.NextInvoiceAt)
+	})
+
+	t.Run("should not prorate metered plans", func(t *testing.T) {
+		metered := &stripe.SubscriptionPlan{ID: "price_metered", Amount: 5, Currency: "usd", Interval: "month", IntervalCount: 1, UsageType: "metered"}
+
+		preview, err := subscriptions.Prorate(subscription(), basic, metered, 0, halfway)
+		require.NoError(t, err)
+		assert.Equal(t, int64(-500), preview.Credit)
+		assert.Zero(t, preview.Charge)
+	})
+
+	t.Run("should reject other currencies and dates outside the period", func(t *testing.T) {
+		euro := &stripe.SubscriptionPlan{ID: "price_eur", Amount: 1000, Currency: "eur", Interval: "month", IntervalCount: 1}
+
+		_, err := subscriptions.Prorate(subscription(), basic, euro, 0, halfway)
+		assert.ErrorIs(t, err, subscriptions.ErrCurrencyMismatch)
+
+		_, err = subscriptions.Prorate(subscription(), basic, pro, 0, periodEnd)
+		assert.ErrorIs(t, err, subscriptions.ErrProrationDate)
+	})
+
+	t.Run("should calculate previews for providers that can't preview", func(t *testing.T) {
+		service, provider := setup()
+
+		preview, err := service.Preview(ctx, "sub_1", &subscriptions.PreviewRequest{PriceID: "price_pro", ProrationDate: &halfway})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1000), preview.ProratedAmount)
+		assert.Equal(t, "price_pro", preview.PriceID)
+		assert.Zero(t, provider.calls, "previews change nothing")
+
+		_, err = service.Preview(ctx, "sub_1", &subscriptions.PreviewRequest{})
+		assert.ErrorIs(t, err, subscriptions.ErrEmptyChange)
+
+		_, err = service.Preview(ctx, "sub_1", &subscriptions.PreviewRequest{Quantity: -1})
+		assert.ErrorIs(t, err, subscriptions.ErrInvalidQuantity)
+	})
+
+	t.Run("should preview at providers that can", func(t *testing.T) {
+		provider := &MockPreviewingSubscriptionProvider{MockSubscriptionProvider: NewMockSubscriptionProvider()}
+		service := subscriptions.NewService(provider, nil)
+
+		preview, err := service.Preview(ctx, "sub_1", &subscriptions.PreviewRequest{Quantity: 2})
+		require.NoError(t, err)
+		assert.Equal(t, stripe.ProrationSourceProvider, preview.Source)
+		assert.Equal(t, int64(2), provider.previewed.Quantity)
+	})
+
+	t.Run("should apply changes with or without proration", func(t *testing.T) {
+		service, provider := setup()
+		prorate := false
+
+		updated, err := service.Update(ctx, "sub_1", &subscriptions.UpdateRequest{Quantity: 5, Prorate: &prorate})
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), updated.Quantity)
+		require.Len(t, provider.changes, 1)
+		assert.False(t, *provider.changes[0].Prorate)
+
+		_, err = service.Update(ctx, "sub_1", &subscriptions.UpdateRequest{PriceID: "price_pro", ProrationDate: &halfway})
+		require.NoError(t, err)
+		assert.Nil(t, provider.changes[1].Prorate, "prorated by default")
+		assert.Equal(t, halfway, *provider.changes[1].ProrationDate)
+	})
+}
+
+// MockPreviewingSubscriptionProvider previews changes at the provider
+type MockPreviewingSubscriptionProvider struct {
+	*MockSubscriptionProvider
+	previewed *stripe.SubscriptionChange
+}
+
+func (m *MockPreviewingSubscriptionProvider) PreviewSubscriptionChange(ctx context.Context, subscriptionID string, change *stripe.SubscriptionChange) (*stripe.ProrationPreview, error) {
+	m.previewed = change
+	return &stripe.ProrationPreview{SubscriptionID: subscriptionID, Quantity: change.Quantity, Source: stripe.ProrationSourceProvider}, nil
+}
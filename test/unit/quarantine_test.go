@@ -0,0 +1,271 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"apis/payments/services/quarantine"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestQuarantine tests holding mutations from quarantined API keys and tenants
+func TestQuarantine(t *testing.T) {
+	setup := func() (*quarantine.Service, *MockQuarantineStore, *MockReplayer) {
+		store := NewMockQuarantineStore()
+		replayer := &MockReplayer{status: 200, body: []byte(`{"id":"ch_1"}`)}
+		return quarantine.NewService(store, replayer), store, replayer
+	}
+
+	caller := quarantine.Caller{TenantID: "acme", APIKeyID: "key_1", OperatorID: "op_1"}
+
+	t.Run("should hold mutations and let reads through, flagging both", func(t *testing.T) {
+		service, store, _ := setup()
+		active, err := service.Quarantine(context.Background(), &quarantine.Quarantine{SubjectType: quarantine.SubjectAPIKey, SubjectID: "key_1"})
+		require.NoError(t, err)
+
+		held, err := service.Flag(context.Background(), active, caller, &quarantine.Request{Method: "GET", Path: "/api/v1/charges"})
+		require.NoError(t, err)
+		assert.Nil(t, held)
+
+		held, err = service.Flag(context.Background(), active, caller, &quarantine.Request{
+			Method: "POST", Path: "/api/v1/charges", ContentType: "application/json", Body: []byte(`{"amount":100}`),
+		})
+		require.NoError(t, err)
+		require.NotNil(t, held)
+		assert.Equal(t, quarantine.MutationPending, held.Status)
+		assert.Equal(t, `{"amount":100}`, held.Body)
+
+		require.Len(t, store.activity, 2)
+		assert.Empty(t, store.activity[0].HeldMutationID)
+		assert.Equal(t, held.ID, store.activity[1].HeldMutationID)
+	})
+
+	t.Run("should match an API key before its tenant", func(t *testing.T) {
+		service, _, _ := setup()
+
+		found, err := service.Check(context.Background(), caller)
+		require.NoError(t, err)
+		assert.Nil(t, found)
+
+		_, err = service.Quarantine(context.Background(), &quarantine.Quarantine{SubjectType: quarantine.SubjectTenant, SubjectID: "acme"})
+		require.NoError(t, err)
+		keyQuarantine, err := service.Quarantine(context.Background(), &quarantine.Quarantine{SubjectType: quarantine.SubjectAPIKey, SubjectID: "key_1"})
+		require.NoError(t, err)
+
+		found, err = service.Check(context.Background(), caller)
+		require.NoError(t, err)
+		assert.Equal(t, keyQuarantine.ID, found.ID)
+
+		found, err = service.Check(context.Background(), quarantine.Caller{TenantID: "acme", APIKeyID: "key_2"})
+		require.NoError(t, err)
+		assert.Equal(t, quarantine.SubjectTenant, found.SubjectType)
+	})
+
+	t.Run("should allow one active quarantine per subject", func(t *testing.T) {
+		service, _, _ := setup()
+		first, err := service.Quarantine(context.Background(), &quarantine.Quarantine{SubjectType: quarantine.SubjectTenant, SubjectID: "acme"})
+		require.NoError(t, err)
+
+		_, err = service.Quarantine(context.Background(), &quarantine.Quarantine{SubjectType: quarantine.SubjectTenant, SubjectID: "acme"})
+		assert.ErrorIs(t, err, quarantine.ErrAlreadyQuarantined)
+
+		_, err = service.Lift(context.Background(), first.ID, "op_2")
+		require.NoError(t, err)
+		_, err = service.Lift(context.Background(), first.ID, "op_2")
+		assert.ErrorIs(t, err, quarantine.ErrNotQuarantined)
+
+		_, err = service.Quarantine(context.Background(), &quarantine.Quarantine{SubjectType: quarantine.SubjectTenant, SubjectID: "acme"})
+		assert.NoError(t, err)
+
+		_, err = service.Quarantine(context.Background(), &quarantine.Quarantine{SubjectType: "operator", SubjectID: "op_1"})
+		assert.ErrorIs(t, err, quarantine.ErrInvalidQuarantine)
+	})
+
+	t.Run("should replay a released mutation once and keep its response", func(t *testing.T) {
+		service, _, replayer := setup()
+		active, err := service.Quarantine(context.Background(), &quarantine.Quarantine{SubjectType: quarantine.SubjectTenant, SubjectID: "acme"})
+		require.NoError(t, err)
+		held, err := service.Flag(context.Background(), active, caller, &quarantine.Request{Method: "POST", Path: "/api/v1/refunds"})
+		require.NoError(t, err)
+
+		released, err := service.Release(context.Background(), held.ID, "op_2")
+		require.NoError(t, err)
+		assert.Equal(t, quarantine.MutationReleased, released.Status)
+		assert.Equal(t, 200, released.ResponseStatus)
+		assert.Equal(t, `{"id":"ch_1"}`, released.ResponseBody)
+		assert.Equal(t, "op_2", released.DecidedBy)
+
+		_, err = service.Release(context.Background(), held.ID, "op_2")
+		assert.ErrorIs(t, err, quarantine.ErrNotPending)
+		assert.Equal(t, 1, replayer.calls)
+	})
+
+	t.Run("should mark failed replays", func(t *testing.T) {
+		service, _, replayer := setup()
+		replayer.err = errors.New("connection reset")
+		active, err := service.Quarantine(context.Background(), &quarantine.Quarantine{SubjectType: quarantine.SubjectTenant, SubjectID: "acme"})
+		require.NoError(t, err)
+		held, err := service.Flag(context.Background(), active, caller, &quarantine.Request{Method: "DELETE", Path: "/api/v1/customers/cus_1"})
+		require.NoError(t, err)
+
+		released, err := service.Release(context.Background(), held.ID, "op_2")
+		require.NoError(t, err)
+		assert.Equal(t, quarantine.MutationFailed, released.Status)
+		assert.Equal(t, "connection reset", released.ResponseBody)
+	})
+
+	t.Run("should never replay rejected mutations", func(t *testing.T) {
+		service, _, replayer := setup()
+		active, err := service.Quarantine(context.Background(), &quarantine.Quarantine{SubjectType: quarantine.SubjectTenant, SubjectID: "acme"})
+		require.NoError(t, err)
+		held, err := service.Flag(context.Background(), active, caller, &quarantine.Request{Method: "POST", Path: "/api/v1/charges"})
+		require.NoError(t, err)
+
+		_, err = service.Reject(context.Background(), held.ID, "")
+		assert.Error(t, err)
+
+		rejected, err := service.Reject(context.Background(), held.ID, "op_2")
+		require.NoError(t, err)
+		assert.Equal(t, quarantine.MutationRejected, rejected.Status)
+
+		_, err = service.Release(context.Background(), held.ID, "op_2")
+		assert.ErrorIs(t, err, quarantine.ErrNotPending)
+		assert.Zero(t, replayer.calls)
+	})
+}
+
+// MockQuarantineStore keeps quarantines, activity and held mutations in memory
+type MockQuarantineStore struct {
+	quarantines map[string]*quarantine.Quarantine
+	activity    []*quarantine.Activity
+	mutations   map[string]*quarantine.HeldMutation
+}
+
+// NewMockQuarantineStore creates an empty quarantine store
+func NewMockQuarantineStore() *MockQuarantineStore {
+	return &MockQuarantineStore{
+		quarantines: make(map[string]*quarantine.Quarantine),
+		mutations:   make(map[string]*quarantine.HeldMutation),
+	}
+}
+
+func (m *MockQuarantineStore) CreateQuarantine(ctx context.Context, q *quarantine.Quarantine) (*quarantine.Quarantine, error) {
+	q.CreatedAt = time.Now()
+	m.quarantines[q.ID] = q
+	return q, nil
+}
+
+func (m *MockQuarantineStore) GetQuarantine(ctx context.Context, id string) (*quarantine.Quarantine, error) {
+	q, ok := m.quarantines[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return q, nil
+}
+
+func (m *MockQuarantineStore) GetActiveQuarantine(ctx context.Context, subjectType, subjectID string) (*quarantine.Quarantine, error) {
+	for _, q := range m.quarantines {
+		if q.SubjectType == subjectType && q.SubjectID == subjectID && q.LiftedAt == nil {
+			return q, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *MockQuarantineStore) ListActiveQuarantines(ctx context.Context) ([]*quarantine.Quarantine, error) {
+	var active []*quarantine.Quarantine
+	for _, q := range m.quarantines {
+		if q.LiftedAt == nil {
+			active = append(active, q)
+		}
+	}
+	return active, nil
+}
+
+func (m *MockQuarantineStore) LiftQuarantine(ctx context.Context, id, liftedBy string) (*quarantine.Quarantine, error) {
+	q, ok := m.quarantines[id]
+	if !ok || q.LiftedAt != nil {
+		return nil, sql.ErrNoRows
+	}
+	now := time.Now()
+	q.LiftedBy, q.LiftedAt = liftedBy, &now
+	return q, nil
+}
+
+func (m *MockQuarantineStore) RecordQuarantineActivity(ctx context.Context, activity *quarantine.Activity) error {
+	m.activity = append(m.activity, activity)
+	return nil
+}
+
+func (m *MockQuarantineStore) ListQuarantineActivity(ctx context.Context, quarantineID string, limit int) ([]*quarantine.Activity, error) {
+	var activity []*quarantine.Activity
+	for _, a := range m.activity {
+		if a.QuarantineID == quarantineID && len(activity) < limit {
+			activity = append(activity, a)
+		}
+	}
+	return activity, nil
+}
+
+func (m *MockQuarantineStore) CreateHeldMutation(ctx context.Context, mutation *quarantine.HeldMutation) (*quarantine.HeldMutation, error) {
+	m.mutations[mutation.ID] = mutation
+	return mutation, nil
+}
+
+func (m *MockQuarantineStore) GetHeldMutation(ctx context.Context, id string) (*quarantine.HeldMutation, error) {
+	mutation, ok := m.mutations[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return mutation, nil
+}
+
+func (m *MockQuarantineStore) ListHeldMutations(ctx context.Context, status string) ([]*quarantine.HeldMutation, error) {
+	var mutations []*quarantine.HeldMutation
+	for _, mutation := range m.mutations {
+		if status == "" || mutation.Status == status {
+			mutations = append(mutations, mutation)
+		}
+	}
+	return mutations, nil
+}
+
+func (m *MockQuarantineStore) DecideHeldMutation(ctx context.Context, id, status, decidedBy string) (*quarantine.HeldMutation, error) {
+	mutation, ok := m.mutations[id]
+	if !ok || mutation.Status != quarantine.MutationPending {
+		return nil, sql.ErrNoRows
+	}
+	now := time.Now()
+	mutation.Status, mutation.DecidedBy, mutation.DecidedAt = status, decidedBy, &now
+	return mutation, nil
+}
+
+func (m *MockQuarantineStore) RecordHeldMutationResult(ctx context.Context, id, status string, responseStatus int, responseBody string) (*quarantine.HeldMutation, error) {
+	mutation, ok := m.mutations[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	mutation.Status, mutation.ResponseStatus, mutation.ResponseBody = status, responseStatus, responseBody
+	return mutation, nil
+}
+
+// MockReplayer returns a fixed response and counts replays
+type MockReplayer struct {
+	status int
+	body   []byte
+	err    error
+	calls  int
+}
+
+func (m *MockReplayer) Replay(ctx context.Context, mutation *quarantine.HeldMutation) (int, []byte, error) {
+	m.calls++
+	if m.err != nil {
+		return 0, nil, m.err
+	}
+	return m.status, m.body, nil
+}
@@ -0,0 +1,151 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"apis/payments/services/ratelimit"
+	"apis/payments/services/redis"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRateLimit tests token buckets, per-endpoint limits and usage counters
+func TestRateLimit(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should parse per-endpoint limits", func(t *testing.T) {
+		rules, err := ratelimit.ParseRules("POST /charges=5:10, post /refunds/*=0.5:2")
+		require.NoError(t, err)
+		require.Len(t, rules, 2)
+		assert.Equal(t, "POST /charges", rules[0].Endpoint())
+		assert.Equal(t, 5.0, rules[0].Rate)
+		assert.Equal(t, 10, rules[0].Burst)
+		assert.Equal(t, "POST /refunds/*", rules[1].Endpoint())
+		assert.Equal(t, 0.5, rules[1].Rate)
+
+		for _, invalid := range []string{"POST /charges", "/charges=5:10", "POST /charges=5", "POST /charges=0:10", "POST /charges=5:x"} {
+			_, err := ratelimit.ParseRules(invalid)
+			assert.Error(t, err, invalid)
+		}
+	})
+
+	t.Run("should refill buckets over time up to the burst", func(t *testing.T) {
+		store := ratelimit.NewMemoryStore()
+		limit := ratelimit.Limit{Rate: 2, Burst: 3}
+		start := time.Now()
+
+		for i := 0; i < 3; i++ {
+			allowed, _, err := store.Take(ctx, "key", limit, start)
+			require.NoError(t, err)
+			assert.True(t, allowed)
+		}
+		allowed, tokens, err := store.Take(ctx, "key", limit, start)
+		require.NoError(t, err)
+		assert.False(t, allowed)
+		assert.Equal(t, 0.0, tokens)
+
+		allowed, tokens, err = store.Take(ctx, "key", limit, start.Add(500*time.Millisecond))
+		require.NoError(t, err)
+		assert.True(t, allowed)
+		assert.InDelta(t, 0, tokens, 1e-9)
+
+		_, tokens, err = store.Take(ctx, "key", limit, start.Add(time.Hour))
+		require.NoError(t, err)
+		assert.InDelta(t, 2, tokens, 1e-9)
+	})
+
+	t.Run("should deny requests over the limit with a retry delay", func(t *testing.T) {
+		service := ratelimit.NewService(ratelimit.NewMemoryStore(), &ratelimit.Config{
+			Enabled: true,
+			Default: ratelimit.Limit{Rate: 100, Burst: 100},
+			Rules:   []ratelimit.Rule{{Method: "POST", Path: "/charges", Limit: ratelimit.Limit{Rate: 0.1, Burst: 2}}},
+		})
+
+		decision, err := service.Allow(ctx, "api_key:1", "POST", "/charges")
+		require.NoError(t, err)
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, "POST /charges", decision.Endpoint)
+		assert.Equal(t, 2, decision.Limit)
+		assert.Equal(t, 1, decision.Remaining)
+
+		// Paths below the rule's share its bucket
+		decision, err = service.Allow(ctx, "api_key:1", "POST", "/charges/ch_1/capture")
+		require.NoError(t, err)
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, 0, decision.Remaining)
+
+		decision, err = service.Allow(ctx, "api_key:1", "POST", "/charges")
+		require.NoError(t, err)
+		assert.False(t, decision.Allowed)
+		assert.InDelta(t, 10, decision.RetryAfter.Seconds(), 0.5)
+		assert.InDelta(t, 20, decision.Reset.Seconds(), 0.5)
+
+		// Other endpoints and other callers have their own buckets
+		decision, err = service.Allow(ctx, "api_key:1", "GET", "/charges")
+		require.NoError(t, err)
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, ratelimit.DefaultEndpoint, decision.Endpoint)
+
+		decision, err = service.Allow(ctx, "api_key:2", "POST", "/charges")
+		require.NoError(t, err)
+		assert.True(t, decision.Allowed)
+	})
+
+	t.Run("should count usage per subject and endpoint", func(t *testing.T) {
+		service := ratelimit.NewService(ratelimit.NewMemoryStore(), &ratelimit.Config{
+			Enabled: true,
+			Default: ratelimit.Limit{Rate: 0.1, Burst: 1},
+		})
+
+		for i := 0; i < 3; i++ {
+			_, err := service.Allow(ctx, "tenant:acme", "GET", "/customers")
+			require.NoError(t, err)
+		}
+		_, err := service.Allow(ctx, "tenant:globex", "GET", "/customers")
+		require.NoError(t, err)
+
+		usage := service.Usage("")
+		require.Len(t, usage, 2)
+		assert.Equal(t, "tenant:acme", usage[0].Subject)
+		assert.Equal(t, int64(1), usage[0].Allowed)
+		assert.Equal(t, int64(2), usage[0].Limited)
+		assert.NotNil(t, usage[0].LastLimitedAt)
+		assert.Nil(t, usage[1].LastLimitedAt)
+
+		usage = service.Usage("tenant:globex")
+		require.Len(t, usage, 1)
+		assert.Equal(t, int64(1), usage[0].Allowed)
+	})
+
+	t.Run("should take tokens from redis", func(t *testing.T) {
+		addr, commands := startFakeRedis(t, "*2\r\n:0\r\n$4\r\n0.25\r\n")
+
+		config, err := redis.ParseURL("redis://" + addr)
+		require.NoError(t, err)
+		store := ratelimit.NewRedisStore(redis.NewClient(config))
+
+		allowed, tokens, err := store.Take(ctx, "tenant:acme|default", ratelimit.Limit{Rate: 2, Burst: 4}, time.UnixMilli(1700000000000))
+		require.NoError(t, err)
+		assert.False(t, allowed)
+		assert.Equal(t, 0.25, tokens)
+
+		command := <-commands
+		require.True(t, len(command) >= 8)
+		assert.Equal(t, "EVAL", command[0])
+		assert.Equal(t, []string{"1", "ratelimit:tenant:acme|default", "2", "4", "1700000000000", "3000"}, command[2:])
+	})
+
+	t.Run("should parse redis URLs", func(t *testing.T) {
+		config, err := redis.ParseURL("redis://:secret@cache.internal/2")
+		require.NoError(t, err)
+		assert.Equal(t, "cache.internal:6379", config.Addr)
+		assert.Equal(t, "secret", config.Password)
+		assert.Equal(t, 2, config.DB)
+
+		_, err = redis.ParseURL("http://cache.internal")
+		assert.Error(t, err)
+	})
+}
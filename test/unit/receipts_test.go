@@ -0,0 +1,138 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"apis/payments/services/receipts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReceipts tests statement descriptor validation, receipt settings and
+// locally generated receipts
+func TestReceipts(t *testing.T) {
+	t.Run("should apply each provider's descriptor rules", func(t *testing.T) {
+		assert.NoError(t, receipts.ValidateDescriptor("stripe", "ACME WIDGETS", ""))
+		assert.NoError(t, receipts.ValidateDescriptor("stripe", "", "ORDER 1234"))
+		assert.NoError(t, receipts.ValidateDescriptor("paypal", "", "ORDER 1234"))
+		assert.NoError(t, receipts.ValidateDescriptor("paddle", "", ""))
+
+		// Too short, too long, or without a letter
+		assert.ErrorIs(t, receipts.ValidateDescriptor("stripe", "ACME", ""), receipts.ErrInvalidDescriptor)
+		assert.ErrorIs(t, receipts.ValidateDescriptor("stripe", "ACME WIDGETS INTERNATIONAL", ""), receipts.ErrInvalidDescriptor)
+		assert.ErrorIs(t, receipts.ValidateDescriptor("stripe", "12345", ""), receipts.ErrInvalidDescriptor)
+
+		// Characters card networks reject
+		assert.ErrorIs(t, receipts.ValidateDescriptor("stripe", "ACME*WIDGETS", ""), receipts.ErrInvalidDescriptor)
+		assert.ErrorIs(t, receipts.ValidateDescriptor("stripe", "ACMÉ WIDGETS", ""), receipts.ErrInvalidDescriptor)
+
+		// Square's identifier is shorter, and only Stripe takes full descriptors
+		assert.ErrorIs(t, receipts.ValidateDescriptor("square", "", "ORDER 1234567890123456"), receipts.ErrInvalidDescriptor)
+		assert.ErrorIs(t, receipts.ValidateDescriptor("paypal", "ACME WIDGETS", ""), receipts.ErrInvalidDescriptor)
+		assert.ErrorIs(t, receipts.ValidateDescriptor("paddle", "", "ORDER 1234"), receipts.ErrInvalidDescriptor)
+
+		assert.Equal(t, 20, receipts.Rules("square").MaxSuffixLength)
+		assert.False(t, receipts.Rules("paddle").Suffix)
+	})
+
+	t.Run("should return default settings for tenants without any", func(t *testing.T) {
+		service := receipts.NewService(NewMockReceiptSettingsStore())
+
+		settings, err := service.Settings(context.Background(), "tenant_1")
+		require.NoError(t, err)
+		assert.Equal(t, "tenant_1", settings.TenantID)
+		assert.False(t, settings.SendReceiptEmails)
+		assert.Empty(t, settings.DescriptorSuffix)
+	})
+
+	t.Run("should save valid settings per tenant", func(t *testing.T) {
+		service := receipts.NewService(NewMockReceiptSettingsStore())
+
+		_, err := service.UpdateSettings(context.Background(), &receipts.Settings{
+			TenantID:          "tenant_1",
+			SendReceiptEmails: true,
+			DescriptorSuffix:  "SHOP",
+			BusinessName:      "Acme Widgets",
+			SupportEmail:      "support@acme.example",
+		})
+		require.NoError(t, err)
+
+		settings, err := service.Settings(context.Background(), "tenant_1")
+		require.NoError(t, err)
+		assert.True(t, settings.SendReceiptEmails)
+		assert.Equal(t, "SHOP", settings.DescriptorSuffix)
+
+		other, err := service.Settings(context.Background(), "tenant_2")
+		require.NoError(t, err)
+		assert.False(t, other.SendReceiptEmails)
+	})
+
+	t.Run("should reject invalid settings", func(t *testing.T) {
+		service := receipts.NewService(NewMockReceiptSettingsStore())
+
+		_, err := service.UpdateSettings(context.Background(), &receipts.Settings{TenantID: "tenant_1", DescriptorSuffix: "<SHOP>"})
+		assert.ErrorIs(t, err, receipts.ErrInvalidSettings)
+
+		_, err = service.UpdateSettings(context.Background(), &receipts.Settings{TenantID: "tenant_1", SupportEmail: "support"})
+		assert.ErrorIs(t, err, receipts.ErrInvalidSettings)
+	})
+
+	t.Run("should render receipts as PDFs", func(t *testing.T) {
+		pdf := string(receipts.RenderPDF(&receipts.Receipt{
+			Number:         "ch_123",
+			BusinessName:   "Café (Acme)",
+			SupportEmail:   "support@acme.example",
+			Amount:         "12,50 €",
+			AmountRefunded: "",
+			Status:         "succeeded",
+			PaidAt:         time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		}))
+
+		assert.True(t, strings.HasPrefix(pdf, "%PDF-1.4\n"))
+		assert.True(t, strings.HasSuffix(pdf, "%%EOF\n"))
+		assert.Contains(t, pdf, `(Caf\351 \(Acme\)) Tj`)
+		assert.Contains(t, pdf, `(12,50 \200) Tj`)
+		assert.Contains(t, pdf, "(Receipt ch_123) Tj")
+		assert.NotContains(t, pdf, "Amount refunded")
+	})
+}
+
+// MockReceiptSettingsStore keeps receipt settings in memory
+type MockReceiptSettingsStore struct {
+	mu       sync.Mutex
+	settings map[string]*receipts.Settings
+}
+
+func NewMockReceiptSettingsStore() *MockReceiptSettingsStore {
+	return &MockReceiptSettingsStore{settings: make(map[string]*receipts.Settings)}
+}
+
+func (m *MockReceiptSettingsStore) GetReceiptSettings(ctx context.Context, tenantID string) (*receipts.Settings, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	settings, ok := m.settings[tenantID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return settings, nil
+}
+
+func (m *MockReceiptSettingsStore) UpsertReceiptSettings(ctx context.Context, settings *receipts.Settings) (*receipts.Settings, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	if existing, ok := m.settings[settings.TenantID]; ok {
+		settings.CreatedAt = existing.CreatedAt
+	} else {
+		settings.CreatedAt = now
+	}
+	settings.UpdatedAt = now
+	m.settings[settings.TenantID] = settings
+	return settings, nil
+}
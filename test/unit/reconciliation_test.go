@@ -0,0 +1,294 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"apis/payments/services/events"
+	"apis/payments/services/reconciliation"
+	"apis/payments/services/stripe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReconciliation tests matching provider balance transactions and
+// payouts against stored charges and refunds
+func TestReconciliation(t *testing.T) {
+	ctx := context.Background()
+	from := time.Date(2026, time.October, 15, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	setup := func() (*reconciliation.Service, *MockReconciliationStore, *MockReconciliationProvider, *MockEventPublisher) {
+		store := NewMockReconciliationStore()
+		provider := &MockReconciliationProvider{bySource: make(map[string][]*stripe.BalanceTransaction), byPayout: make(map[string][]*stripe.BalanceTransaction)}
+		publisher := &MockEventPublisher{}
+		source, _ := events.NewSource("/payments")
+		service := reconciliation.NewService(store, provider, events.NewEmitter(source, publisher), &reconciliation.Config{Hour: 2})
+		return service, store, provider, publisher
+	}
+
+	kinds := func(report *reconciliation.Report) map[string]string {
+		found := make(map[string]string)
+		for _, mismatch := range report.Mismatches {
+			found[mismatch.ObjectID] = mismatch.Kind
+		}
+		return found
+	}
+
+	t.Run("should match transactions to stored charges and refunds", func(t *testing.T) {
+		service, store, provider, publisher := setup()
+		store.charges["ch_1"] = &stripe.Charge{ID: "ch_1", Amount: 2000, Currency: "usd", Captured: true}
+		store.refunds["re_1"] = &stripe.Refund{ID: "re_1", Amount: 500, Currency: "usd", Status: "succeeded"}
+		provider.transactions = []*stripe.BalanceTransaction{
+			{ID: "txn_1", Type: stripe.BalanceTransactionCharge, SourceID: "ch_1", Amount: 2000, Fee: 88, Net: 1912, Currency: "usd"},
+			{ID: "txn_2", Type: stripe.BalanceTransactionRefund, SourceID: "re_1", Amount: -500, Net: -500, Currency: "usd"},
+			{ID: "txn_3", Type: "adjustment", SourceID: "adj_1", Amount: 100, Currency: "usd"},
+		}
+
+		report, err := service.Reconcile(ctx, from, to)
+		require.NoError(t, err)
+
+		assert.Equal(t, reconciliation.StatusCompleted, report.Run.Status)
+		assert.Equal(t, int64(2), report.Run.TransactionsChecked)
+		assert.Equal(t, int64(2), report.Run.Matched)
+		assert.Empty(t, report.Mismatches)
+		assert.Empty(t, publisher.events)
+	})
+
+	t.Run("should flag missing, drifted and orphaned records", func(t *testing.T) {
+		service, store, provider, publisher := setup()
+		store.charges["ch_1"] = &stripe.Charge{ID: "ch_1", Amount: 2000, Currency: "usd", Captured: true}
+		store.charges["ch_3"] = &stripe.Charge{ID: "ch_3", Amount: 700, Currency: "usd", Captured: true}
+		store.charges["ch_4"] = &stripe.Charge{ID: "ch_4", Amount: 900, Currency: "usd", Captured: true}
+		provider.transactions = []*stripe.BalanceTransaction{
+			{ID: "txn_1", Type: stripe.BalanceTransactionCharge, SourceID: "ch_1", Amount: 1800, Currency: "usd"},
+			{ID: "txn_2", Type: stripe.BalanceTransactionCharge, SourceID: "ch_2", Amount: 1000, Currency: "usd"},
+		}
+		// ch_4 was captured after the period
+		provider.bySource["ch_4"] = []*stripe.BalanceTransaction{{ID: "txn_4", Type: stripe.BalanceTransactionCharge, SourceID: "ch_4"}}
+
+		report, err := service.Reconcile(ctx, from, to)
+		require.NoError(t, err)
+
+		assert.Equal(t, map[string]string{
+			"ch_1": reconciliation.KindAmountDrift,
+			"ch_2": reconciliation.KindMissing,
+			"ch_3": reconciliation.KindOrphaned,
+		}, kinds(report))
+		assert.Equal(t, int64(3), report.Run.Mismatches)
+		assert.Len(t, store.mismatches, 3)
+
+		require.Len(t, publisher.events, 3)
+		assert.Equal(t, reconciliation.EventMismatch, publisher.events[0].Type)
+	})
+
+	t.Run("should not compare amounts settled in another currency", func(t *testing.T) {
+		service, store, provider, _ := setup()
+		store.charges["ch_1"] = &stripe.Charge{ID: "ch_1", Amount: 2000, Currency: "eur", Captured: true}
+		provider.transactions = []*stripe.BalanceTransaction{
+			{ID: "txn_1", Type: stripe.BalanceTransactionCharge, SourceID: "ch_1", Amount: 2160, Currency: "usd"},
+		}
+
+		report, err := service.Reconcile(ctx, from, to)
+		require.NoError(t, err)
+		assert.Empty(t, report.Mismatches)
+	})
+
+	t.Run("should check payouts against the net of their transactions", func(t *testing.T) {
+		service, _, provider, _ := setup()
+		provider.payouts = []*stripe.Payout{
+			{ID: "po_1", Amount: 1412, Currency: "usd", Status: stripe.PayoutPaid},
+			{ID: "po_2", Amount: 5000, Currency: "usd", Status: stripe.PayoutPaid},
+			{ID: "po_3", Amount: 5000, Currency: "usd", Status: "in_transit"},
+		}
+		provider.byPayout["po_1"] = []*stripe.BalanceTransaction{
+			{ID: "txn_1", Type: stripe.BalanceTransactionCharge, Net: 1912},
+			{ID: "txn_2", Type: stripe.BalanceTransactionRefund, Net: -500},
+			{ID: "txn_3", Type: stripe.BalanceTransactionPayout, Net: -1412},
+		}
+		provider.byPayout["po_2"] = []*stripe.BalanceTransaction{
+			{ID: "txn_4", Type: stripe.BalanceTransactionCharge, Net: 4900},
+		}
+
+		report, err := service.Reconcile(ctx, from, to)
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(2), report.Run.PayoutsChecked)
+		require.Len(t, report.Mismatches, 1)
+		assert.Equal(t, "po_2", report.Mismatches[0].ObjectID)
+		assert.Equal(t, reconciliation.ObjectPayout, report.Mismatches[0].ObjectType)
+		assert.Equal(t, int64(4900), report.Mismatches[0].LocalAmount)
+	})
+
+	t.Run("should record failed runs", func(t *testing.T) {
+		service, store, provider, _ := setup()
+		provider.err = errors.New("stripe unavailable")
+
+		_, err := service.Reconcile(ctx, from, to)
+		require.Error(t, err)
+
+		require.Len(t, store.runs, 1)
+		for _, run := range store.runs {
+			assert.Equal(t, reconciliation.StatusFailed, run.Status)
+			assert.Contains(t, run.Error, "stripe unavailable")
+			assert.NotNil(t, run.CompletedAt)
+		}
+	})
+
+	t.Run("should report a stored run with its mismatches", func(t *testing.T) {
+		service, _, provider, _ := setup()
+		provider.transactions = []*stripe.BalanceTransaction{
+			{ID: "txn_1", Type: stripe.BalanceTransactionPayment, SourceID: "py_1", Amount: 1000, Currency: "usd"},
+		}
+
+		run, err := service.Reconcile(ctx, from, to)
+		require.NoError(t, err)
+
+		report, err := service.Report(ctx, run.Run.ID)
+		require.NoError(t, err)
+		assert.Equal(t, run.Run.ID, report.Run.ID)
+		require.Len(t, report.Mismatches, 1)
+		assert.Equal(t, reconciliation.KindMissing, report.Mismatches[0].Kind)
+
+		_, err = service.Report(ctx, "recon_unknown")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("should reconcile the previous UTC day", func(t *testing.T) {
+		start, end := reconciliation.PreviousDay(time.Date(2026, time.October, 16, 2, 0, 5, 0, time.UTC))
+		assert.Equal(t, from, start)
+		assert.Equal(t, to, end)
+	})
+}
+
+// MockReconciliationStore is an in-memory reconciliation.Store
+type MockReconciliationStore struct {
+	runs       map[string]*reconciliation.Run
+	mismatches []*reconciliation.Mismatch
+	charges    map[string]*stripe.Charge
+	refunds    map[string]*stripe.Refund
+}
+
+func NewMockReconciliationStore() *MockReconciliationStore {
+	return &MockReconciliationStore{
+		runs:    make(map[string]*reconciliation.Run),
+		charges: make(map[string]*stripe.Charge),
+		refunds: make(map[string]*stripe.Refund),
+	}
+}
+
+func (m *MockReconciliationStore) CreateReconciliationRun(ctx context.Context, run *reconciliation.Run) (*reconciliation.Run, error) {
+	stored := *run
+	m.runs[run.ID] = &stored
+	return &stored, nil
+}
+
+func (m *MockReconciliationStore) CompleteReconciliationRun(ctx context.Context, run *reconciliation.Run) (*reconciliation.Run, error) {
+	if _, ok := m.runs[run.ID]; !ok {
+		return nil, sql.ErrNoRows
+	}
+	stored := *run
+	m.runs[run.ID] = &stored
+	return &stored, nil
+}
+
+func (m *MockReconciliationStore) GetReconciliationRun(ctx context.Context, runID string) (*reconciliation.Run, error) {
+	run, ok := m.runs[runID]
+	if !ok {
+		return nil, fmt.Errorf("failed to get reconciliation run: %w", sql.ErrNoRows)
+	}
+	return run, nil
+}
+
+func (m *MockReconciliationStore) ListReconciliationRuns(ctx context.Context, limit int) ([]*reconciliation.Run, error) {
+	var runs []*reconciliation.Run
+	for _, run := range m.runs {
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+func (m *MockReconciliationStore) CreateReconciliationMismatch(ctx context.Context, mismatch *reconciliation.Mismatch) error {
+	m.mismatches = append(m.mismatches, mismatch)
+	return nil
+}
+
+func (m *MockReconciliationStore) ListReconciliationMismatches(ctx context.Context, runID string) ([]*reconciliation.Mismatch, error) {
+	var mismatches []*reconciliation.Mismatch
+	for _, mismatch := range m.mismatches {
+		if mismatch.RunID == runID {
+			mismatches = append(mismatches, mismatch)
+		}
+	}
+	return mismatches, nil
+}
+
+func (m *MockReconciliationStore) GetCharge(ctx context.Context, chargeID string) (*stripe.Charge, error) {
+	charge, ok := m.charges[chargeID]
+	if !ok {
+		return nil, fmt.Errorf("failed to get charge: %w", sql.ErrNoRows)
+	}
+	return charge, nil
+}
+
+func (m *MockReconciliationStore) GetRefund(ctx context.Context, refundID string) (*stripe.Refund, error) {
+	refund, ok := m.refunds[refundID]
+	if !ok {
+		return nil, fmt.Errorf("failed to get refund: %w", sql.ErrNoRows)
+	}
+	return refund, nil
+}
+
+func (m *MockReconciliationStore) ListCapturedChargesCreatedBetween(ctx context.Context, from, to time.Time) ([]*stripe.Charge, error) {
+	var charges []*stripe.Charge
+	for _, charge := range m.charges {
+		if charge.Captured {
+			charges = append(charges, charge)
+		}
+	}
+	return charges, nil
+}
+
+func (m *MockReconciliationStore) ListSucceededRefundsCreatedBetween(ctx context.Context, from, to time.Time) ([]*stripe.Refund, error) {
+	var refunds []*stripe.Refund
+	for _, refund := range m.refunds {
+		if refund.Status == "succeeded" {
+			refunds = append(refunds, refund)
+		}
+	}
+	return refunds, nil
+}
+
+// MockReconciliationProvider serves fixed balance transactions and payouts
+type MockReconciliationProvider struct {
+	transactions []*stripe.BalanceTransaction
+	bySource     map[string][]*stripe.BalanceTransaction
+	byPayout     map[string][]*stripe.BalanceTransaction
+	payouts      []*stripe.Payout
+	err          error
+}
+
+func (m *MockReconciliationProvider) ListBalanceTransactions(ctx context.Context, filter stripe.BalanceTransactionFilter) ([]*stripe.BalanceTransaction, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	switch {
+	case filter.SourceID != "":
+		return m.bySource[filter.SourceID], nil
+	case filter.PayoutID != "":
+		return m.byPayout[filter.PayoutID], nil
+	}
+	return m.transactions, nil
+}
+
+func (m *MockReconciliationProvider) ListPayouts(ctx context.Context, from, to time.Time) ([]*stripe.Payout, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.payouts, nil
+}
@@ -0,0 +1,197 @@
+package test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"apis/payments/services/cache"
+	"apis/payments/services/ratelimit"
+	"apis/payments/services/redis"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRedis tests how the Redis stores handle nil, error and truncated
+// replies
+func TestRedis(t *testing.T) {
+	ctx := context.Background()
+
+	client := func(addr string) *redis.Client {
+		config, err := redis.ParseURL("redis://" + addr)
+		require.NoError(t, err)
+		config.Timeout = 500 * time.Millisecond
+		client := redis.NewClient(config)
+		t.Cleanup(func() { client.Close() })
+		return client
+	}
+
+	t.Run("should report a nil bulk reply as a cache miss", func(t *testing.T) {
+		addr, commands := startFakeRedis(t, "$-1\r\n")
+		store := cache.NewRedisStore(client(addr))
+
+		_, err := store.Get(ctx, "cache:customer:acme:cus_1")
+
+		assert.ErrorIs(t, err, cache.ErrMiss)
+		assert.Equal(t, []string{"GET", "cache:customer:acme:cus_1"}, <-commands)
+	})
+
+	t.Run("should return an empty bulk reply as an empty value", func(t *testing.T) {
+		addr, _ := startFakeRedis(t, "$0\r\n\r\n")
+		store := cache.NewRedisStore(client(addr))
+
+		value, err := store.Get(ctx, "cache:customer:acme:cus_1")
+
+		require.NoError(t, err)
+		assert.Empty(t, value)
+	})
+
+	t.Run("should return error replies and keep using the connection", func(t *testing.T) {
+		addr, commands := serveFakeRedis(t, func(command []string) (string, bool) {
+			if command[0] == "GET" {
+				return "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n", false
+			}
+			return "+OK\r\n", false
+		})
+		store := cache.NewRedisStore(client(addr))
+
+		_, err := store.Get(ctx, "cache:customer:acme:cus_1")
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, cache.ErrMiss)
+		assert.Contains(t, err.Error(), "WRONGTYPE")
+
+		require.NoError(t, store.Set(ctx, "cache:customer:acme:cus_1", []byte(`{"id":"cus_1"}`), time.Minute))
+		assert.Equal(t, "GET", (<-commands)[0])
+		set := <-commands
+		assert.Equal(t, []string{"SET", "cache:customer:acme:cus_1", `{"id":"cus_1"}`, "ex", "60"}, set)
+	})
+
+	t.Run("should fail on a reply cut off mid-value", func(t *testing.T) {
+		addr, _ := serveFakeRedis(t, func(command []string) (string, bool) {
+			return "$14\r\n{\"id\":\"cu", true
+		})
+		store := cache.NewRedisStore(client(addr))
+
+		_, err := store.Get(ctx, "cache:customer:acme:cus_1")
+
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, cache.ErrMiss)
+	})
+
+	t.Run("should fail on a script error reply", func(t *testing.T) {
+		addr, _ := startFakeRedis(t, "-ERR Error running script: user_script:3: bad argument\r\n")
+		store := ratelimit.NewRedisStore(client(addr))
+
+		_, _, err := store.Take(ctx, "tenant:acme|default", ratelimit.Limit{Rate: 2, Burst: 4}, time.UnixMilli(1700000000000))
+
+		assert.ErrorContains(t, err, "Error running script")
+	})
+
+	t.Run("should fail on an unexpected script reply", func(t *testing.T) {
+		addr, _ := startFakeRedis(t, "*2\r\n:1\r\n$-1\r\n")
+		store := ratelimit.NewRedisStore(client(addr))
+
+		_, _, err := store.Take(ctx, "tenant:acme|default", ratelimit.Limit{Rate: 2, Burst: 4}, time.UnixMilli(1700000000000))
+
+		assert.ErrorContains(t, err, "unexpected rate limit tokens")
+	})
+}
+
+// startFakeRedis answers every command with reply and passes the commands
+// it reads on
+func startFakeRedis(t *testing.T, reply string) (string, <-chan []string) {
+	return serveFakeRedis(t, func([]string) (string, bool) { return reply, false })
+}
+
+// serveFakeRedis serves connections like a Redis server without HELLO,
+// answering each other command with the reply handle returns and closing
+// the connection after it when asked to. Commands are passed on with their
+// name upper-cased.
+func serveFakeRedis(t *testing.T, handle func(command []string) (reply string, closeAfter bool)) (string, <-chan []string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	commands := make(chan []string, 16)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+
+				reader := bufio.NewReader(conn)
+				for {
+					command, err := readFakeRedisCommand(reader)
+					if err != nil {
+						return
+					}
+					command[0] = strings.ToUpper(command[0])
+					if command[0] == "HELLO" {
+						if _, err := conn.Write([]byte("-ERR unknown command 'HELLO'\r\n")); err != nil {
+							return
+						}
+						continue
+					}
+
+					select {
+					case commands <- command:
+					default:
+					}
+					reply, closeAfter := handle(command)
+					if _, err := conn.Write([]byte(reply)); err != nil || closeAfter {
+						return
+					}
+				}
+			}()
+		}
+	}()
+
+	return listener.Addr().String(), commands
+}
+
+// readFakeRedisCommand reads a command sent as a RESP array of bulk strings
+func readFakeRedisCommand(reader *bufio.Reader) ([]string, error) {
+	var count int
+	if _, err := readFakeRedisHeader(reader, '*', &count); err != nil {
+		return nil, err
+	}
+
+	command := make([]string, count)
+	for i := range command {
+		var size int
+		if _, err := readFakeRedisHeader(reader, '$', &size); err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, buf); err != nil {
+			return nil, err
+		}
+		command[i] = string(buf[:size])
+	}
+
+	return command, nil
+}
+
+// readFakeRedisHeader reads a line such as "*3" or "$5" into n
+func readFakeRedisHeader(reader *bufio.Reader, prefix byte, n *int) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" || line[0] != prefix {
+		return "", fmt.Errorf("unexpected line %q", line)
+	}
+	*n, err = strconv.Atoi(line[1:])
+	return line, err
+}
@@ -0,0 +1,223 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"testing"
+	"time"
+
+	"apis/payments/services/chargestate"
+	"apis/payments/services/refundbatches"
+	"apis/payments/services/refundguard"
+	"apis/payments/services/stripe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRefundBatches tests issuing batches of refunds and scheduled refunds
+// in the background
+func TestRefundBatches(t *testing.T) {
+	ctx := context.Background()
+	actor := refundguard.Actor{TenantID: "tenant_1", OperatorID: "op_1"}
+	config := &refundbatches.Config{MaxSize: 3, Concurrency: 2, Interval: time.Minute, BatchSize: 10}
+
+	setup := func() (*refundbatches.Service, *MockRefundBatchStore, *MockBatchRefunder) {
+		store := &MockRefundBatchStore{batches: map[string]*refundbatches.Batch{}, items: map[string]map[int]*refundbatches.Item{}}
+		refunder := &MockBatchRefunder{}
+		states := MockBatchChargeStates{"ch_voided": chargestate.ErrIllegalTransition}
+		return refundbatches.NewService(store, refunder, states, config), store, refunder
+	}
+
+	t.Run("should reject empty and oversized batches", func(t *testing.T) {
+		service, _, _ := setup()
+
+		_, err := service.Create(ctx, actor, refundbatches.CreateRequest{})
+		assert.ErrorIs(t, err, refundbatches.ErrEmptyBatch)
+
+		_, err = service.Create(ctx, actor, refundbatches.CreateRequest{Refunds: make([]stripe.RefundRequest, 4)})
+		assert.ErrorIs(t, err, refundbatches.ErrBatchTooLarge)
+	})
+
+	t.Run("should record the result of every refund", func(t *testing.T) {
+		service, _, refunder := setup()
+		batch, err := service.Create(ctx, actor, refundbatches.CreateRequest{Refunds: []stripe.RefundRequest{
+			{ChargeID: "ch_1", Amount: 1000},
+			{ChargeID: "ch_held", Amount: 90000},
+			{ChargeID: "ch_voided", Amount: 500},
+		}})
+		require.NoError(t, err)
+		assert.Equal(t, refundbatches.StatusPending, batch.Status)
+		assert.Equal(t, 0, batch.Progress.Processed)
+
+		completed, err := service.RunDue(ctx, time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, 1, completed)
+
+		batch, err = service.Get(ctx, "tenant_1", batch.ID)
+		require.NoError(t, err)
+		assert.Equal(t, refundbatches.StatusCompleted, batch.Status)
+		assert.Equal(t, refundbatches.Progress{Total: 3, Processed: 3, Succeeded: 1, PendingApproval: 1, Failed: 1}, batch.Progress)
+		assert.Equal(t, "re_ch_1", batch.Items[0].RefundID)
+		assert.Equal(t, "rfa_ch_held", batch.Items[1].ApprovalID)
+		assert.Contains(t, batch.Items[2].Error, "illegal")
+		assert.ElementsMatch(t, []string{batch.ID + "-0", batch.ID + "-1"}, refunder.keys())
+	})
+
+	t.Run("should wait for a scheduled refund's time", func(t *testing.T) {
+		service, _, refunder := setup()
+		scheduledAt := time.Now().Add(24 * time.Hour)
+		batch, err := service.Create(ctx, actor, refundbatches.CreateRequest{
+			Refunds:     []stripe.RefundRequest{{ChargeID: "ch_1"}},
+			ScheduledAt: &scheduledAt,
+		})
+		require.NoError(t, err)
+
+		completed, err := service.RunDue(ctx, time.Now())
+		require.NoError(t, err)
+		assert.Zero(t, completed)
+		assert.Empty(t, refunder.keys())
+
+		completed, err = service.RunDue(ctx, scheduledAt.Add(time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, 1, completed)
+		assert.Equal(t, []string{batch.ID + "-0"}, refunder.keys())
+	})
+
+	t.Run("should skip refunds already issued when a batch is picked up again", func(t *testing.T) {
+		service, store, refunder := setup()
+		batch, err := service.Create(ctx, actor, refundbatches.CreateRequest{Refunds: []stripe.RefundRequest{{ChargeID: "ch_1"}, {ChargeID: "ch_2"}}})
+		require.NoError(t, err)
+		require.NoError(t, store.RecordRefundBatchItem(ctx, batch.ID, &refundbatches.Item{Index: 0, Status: refundbatches.ItemSucceeded, RefundID: "re_earlier"}))
+
+		_, err = service.RunDue(ctx, time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, []string{batch.ID + "-1"}, refunder.keys())
+
+		batch, err = service.Get(ctx, "tenant_1", batch.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "re_earlier", batch.Items[0].RefundID)
+		assert.Equal(t, 2, batch.Progress.Succeeded)
+	})
+
+	t.Run("should only cancel batches that have not started", func(t *testing.T) {
+		service, store, _ := setup()
+		scheduledAt := time.Now().Add(time.Hour)
+		batch, err := service.Create(ctx, actor, refundbatches.CreateRequest{Refunds: []stripe.RefundRequest{{ChargeID: "ch_1"}}, ScheduledAt: &scheduledAt})
+		require.NoError(t, err)
+
+		_, err = service.Cancel(ctx, "tenant_2", batch.ID)
+		assert.ErrorIs(t, err, refundbatches.ErrBatchNotFound)
+
+		canceled, err := service.Cancel(ctx, "tenant_1", batch.ID)
+		require.NoError(t, err)
+		assert.Equal(t, refundbatches.StatusCanceled, canceled.Status)
+
+		completed, err := service.RunDue(ctx, scheduledAt.Add(time.Minute))
+		require.NoError(t, err)
+		assert.Zero(t, completed)
+
+		store.batches[batch.ID].Status = refundbatches.StatusRunning
+		_, err = service.Cancel(ctx, "tenant_1", batch.ID)
+		assert.ErrorIs(t, err, refundbatches.ErrNotCancelable)
+	})
+}
+
+// MockRefundBatchStore keeps batches and item results in memory
+type MockRefundBatchStore struct {
+	mu      sync.Mutex
+	batches map[string]*refundbatches.Batch
+	items   map[string]map[int]*refundbatches.Item
+}
+
+func (m *MockRefundBatchStore) CreateRefundBatch(ctx context.Context, batch *refundbatches.Batch) (*refundbatches.Batch, error) {
+	stored := *batch
+	m.batches[batch.ID] = &stored
+	created := stored
+	return &created, nil
+}
+
+func (m *MockRefundBatchStore) GetRefundBatch(ctx context.Context, id string) (*refundbatches.Batch, error) {
+	batch, ok := m.batches[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	found := *batch
+	return &found, nil
+}
+
+func (m *MockRefundBatchStore) ListRunnableRefundBatches(ctx context.Context, now time.Time, limit int) ([]*refundbatches.Batch, error) {
+	var runnable []*refundbatches.Batch
+	for _, batch := range m.batches {
+		if batch.Status == refundbatches.StatusPending && !batch.ScheduledAt.After(now) {
+			found := *batch
+			runnable = append(runnable, &found)
+		}
+	}
+	return runnable, nil
+}
+
+func (m *MockRefundBatchStore) ClaimRefundBatch(ctx context.Context, id string) (*refundbatches.Batch, error) {
+	return m.FinishRefundBatch(ctx, id, refundbatches.StatusPending, refundbatches.StatusRunning)
+}
+
+func (m *MockRefundBatchStore) FinishRefundBatch(ctx context.Context, id, from, status string) (*refundbatches.Batch, error) {
+	batch, ok := m.batches[id]
+	if !ok || batch.Status != from {
+		return nil, sql.ErrNoRows
+	}
+	batch.Status = status
+	updated := *batch
+	return &updated, nil
+}
+
+func (m *MockRefundBatchStore) RecordRefundBatchItem(ctx context.Context, batchID string, item *refundbatches.Item) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.items[batchID] == nil {
+		m.items[batchID] = map[int]*refundbatches.Item{}
+	}
+	m.items[batchID][item.Index] = item
+	return nil
+}
+
+func (m *MockRefundBatchStore) ListRefundBatchItems(ctx context.Context, batchID string) ([]*refundbatches.Item, error) {
+	var items []*refundbatches.Item
+	for _, item := range m.items[batchID] {
+		recorded := *item
+		items = append(items, &recorded)
+	}
+	return items, nil
+}
+
+// MockBatchRefunder issues refunds, holding those of ch_held, and records
+// their idempotency keys
+type MockBatchRefunder struct {
+	mu              sync.Mutex
+	idempotencyKeys []string
+}
+
+func (m *MockBatchRefunder) CreateRefund(ctx context.Context, actor refundguard.Actor, request *stripe.RefundRequest) (*stripe.Refund, *refundguard.Approval, error) {
+	m.mu.Lock()
+	m.idempotencyKeys = append(m.idempotencyKeys, request.IdempotencyKey)
+	m.mu.Unlock()
+
+	if request.ChargeID == "ch_held" {
+		return nil, &refundguard.Approval{ID: "rfa_" + request.ChargeID, Amount: request.Amount, Status: refundguard.ApprovalPending}, nil
+	}
+	return &stripe.Refund{ID: "re_" + request.ChargeID, ChargeID: request.ChargeID, Amount: request.Amount}, nil, nil
+}
+
+func (m *MockBatchRefunder) keys() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.idempotencyKeys...)
+}
+
+// MockBatchChargeStates rejects refunds of the charges it holds an error for
+type MockBatchChargeStates map[string]error
+
+func (m MockBatchChargeStates) Allows(ctx context.Context, chargeID string, to ...string) error {
+	return m[chargeID]
+}
@@ -0,0 +1,398 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"apis/payments/services/events"
+	"apis/payments/services/refundguard"
+	"apis/payments/services/stripe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRefundGuard tests refund velocity limits and step-up approval
+func TestRefundGuard(t *testing.T) {
+	ctx := context.Background()
+	actor := refundguard.Actor{TenantID: "tenant_1", APIKeyID: "key_1", OperatorID: "op_1"}
+	limits := &refundguard.Limits{
+		Window:         time.Hour,
+		BaselinePeriod: 24 * time.Hour,
+		Multiplier:     3,
+		MinCount:       2,
+		MinAmount:      10000,
+		HardAmount:     50000,
+	}
+
+	t.Run("should scale the threshold with the baseline", func(t *testing.T) {
+		threshold := limits.Threshold(refundguard.Usage{Count: 240, Amount: 2400000})
+
+		assert.Equal(t, int64(30), threshold.Count)
+		assert.Equal(t, int64(300000), threshold.Amount)
+	})
+
+	t.Run("should apply floors when there is no history", func(t *testing.T) {
+		threshold := limits.Threshold(refundguard.Usage{})
+
+		assert.Equal(t, int64(2), threshold.Count)
+		assert.Equal(t, int64(10000), threshold.Amount)
+	})
+
+	t.Run("should issue refunds within the baseline", func(t *testing.T) {
+		store := NewMockRefundGuardStore()
+		refunder := &MockRefunder{}
+		service := refundguard.NewService(store, refunder, nil, &MockAlerter{}, limits)
+
+		refund, approval, err := service.CreateRefund(ctx, actor, &stripe.RefundRequest{ChargeID: "ch_1", Amount: 1000})
+
+		require.NoError(t, err)
+		assert.NotNil(t, refund)
+		assert.Nil(t, approval)
+		assert.Len(t, store.activity, 1)
+	})
+
+	t.Run("should hold refunds and alert when the count spikes", func(t *testing.T) {
+		store := NewMockRefundGuardStore()
+		refunder := &MockRefunder{}
+		alerter := &MockAlerter{}
+		service := refundguard.NewService(store, refunder, nil, alerter, limits)
+
+		for i := 0; i < 2; i++ {
+			_, approval, err := service.CreateRefund(ctx, actor, &stripe.RefundRequest{ChargeID: "ch_1", Amount: 100})
+			require.NoError(t, err)
+			require.Nil(t, approval)
+		}
+
+		refund, approval, err := service.CreateRefund(ctx, actor, &stripe.RefundRequest{ChargeID: "ch_1", Amount: 100})
+
+		require.NoError(t, err)
+		assert.Nil(t, refund)
+		require.NotNil(t, approval)
+		assert.Equal(t, refundguard.ApprovalPending, approval.Status)
+		assert.Equal(t, 2, refunder.calls)
+		require.Len(t, alerter.alerts, 1)
+		assert.Equal(t, approval.ID, alerter.alerts[0].ApprovalID)
+	})
+
+	t.Run("should hold refunds over the hard limit", func(t *testing.T) {
+		store := NewMockRefundGuardStore()
+		service := refundguard.NewService(store, &MockRefunder{}, nil, &MockAlerter{}, limits)
+
+		_, approval, err := service.CreateRefund(ctx, actor, &stripe.RefundRequest{ChargeID: "ch_1", Amount: 60000})
+
+		require.NoError(t, err)
+		require.NotNil(t, approval)
+		assert.True(t, approval.Violations[0].Hard)
+	})
+
+	t.Run("should require a different operator to approve", func(t *testing.T) {
+		store := NewMockRefundGuardStore()
+		refunder := &MockRefunder{}
+		service := refundguard.NewService(store, refunder, nil, &MockAlerter{}, limits)
+
+		_, approval, err := service.CreateRefund(ctx, actor, &stripe.RefundRequest{ChargeID: "ch_1", Amount: 60000})
+		require.NoError(t, err)
+
+		_, err = service.ApproveRefund(ctx, approval.ID, refundguard.Actor{TenantID: "tenant_1", APIKeyID: "key_2", OperatorID: "op_1"})
+		assert.ErrorIs(t, err, refundguard.ErrSelfApproval)
+		_, err = service.ApproveRefund(ctx, approval.ID, refundguard.Actor{TenantID: "tenant_1", APIKeyID: "key_1", OperatorID: "op_2"})
+		assert.ErrorIs(t, err, refundguard.ErrSelfApproval, "the requesting key cannot approve under another operator")
+		assert.Equal(t, 0, refunder.calls)
+
+		approved, err := service.ApproveRefund(ctx, approval.ID, refundguard.Actor{TenantID: "tenant_1", APIKeyID: "key_2", OperatorID: "op_2"})
+		require.NoError(t, err)
+		assert.Equal(t, refundguard.ApprovalApproved, approved.Status)
+		assert.Equal(t, "op_2", approved.DecidedBy)
+		assert.Equal(t, 1, refunder.calls)
+	})
+
+	t.Run("should issue a held refund once under concurrent decisions", func(t *testing.T) {
+		store := NewMockRefundGuardStore()
+		refunder := &MockRefunder{}
+		service := refundguard.NewService(store, refunder, nil, &MockAlerter{}, limits)
+
+		_, approval, err := service.CreateRefund(ctx, actor, &stripe.RefundRequest{ChargeID: "ch_1", Amount: 60000})
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		results := make(chan error, 10)
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				approver := refundguard.Actor{TenantID: "tenant_1", APIKeyID: fmt.Sprintf("key_%d", i+2), OperatorID: fmt.Sprintf("op_%d", i+2)}
+				if i%2 == 0 {
+					_, err := service.ApproveRefund(ctx, approval.ID, approver)
+					results <- err
+					return
+				}
+				_, err := service.RejectRefund(ctx, approval.ID, approver)
+				results <- err
+			}(i)
+		}
+		wg.Wait()
+		close(results)
+
+		decided := 0
+		for err := range results {
+			if err == nil {
+				decided++
+				continue
+			}
+			assert.ErrorIs(t, err, refundguard.ErrApprovalDecided)
+		}
+		assert.Equal(t, 1, decided, "only one decision wins")
+
+		final, err := service.GetApproval(ctx, "tenant_1", approval.ID)
+		require.NoError(t, err)
+		switch final.Status {
+		case refundguard.ApprovalApproved:
+			assert.Equal(t, 1, refunder.calls)
+			assert.Equal(t, []string{approval.ID}, refunder.idempotencyKeys, "held refunds are keyed by their approval")
+		case refundguard.ApprovalRejected:
+			assert.Equal(t, 0, refunder.calls, "rejected refunds are never issued")
+		default:
+			t.Fatalf("approval left %s", final.Status)
+		}
+	})
+
+	t.Run("should return a claimed approval to pending when its refund fails", func(t *testing.T) {
+		store := NewMockRefundGuardStore()
+		refunder := &MockRefunder{}
+		service := refundguard.NewService(store, refunder, nil, &MockAlerter{}, limits)
+
+		_, approval, err := service.CreateRefund(ctx, actor, &stripe.RefundRequest{ChargeID: "ch_1", Amount: 60000})
+		require.NoError(t, err)
+
+		refunder.err = assert.AnError
+		_, err = service.ApproveRefund(ctx, approval.ID, refundguard.Actor{TenantID: "tenant_1", APIKeyID: "key_2", OperatorID: "op_2"})
+		assert.ErrorIs(t, err, assert.AnError)
+
+		found, err := service.GetApproval(ctx, "tenant_1", approval.ID)
+		require.NoError(t, err)
+		assert.Equal(t, refundguard.ApprovalPending, found.Status)
+	})
+
+	t.Run("should hide approvals from other tenants", func(t *testing.T) {
+		refunder := &MockRefunder{}
+		service := refundguard.NewService(NewMockRefundGuardStore(), refunder, nil, &MockAlerter{}, limits)
+
+		_, approval, err := service.CreateRefund(ctx, actor, &stripe.RefundRequest{ChargeID: "ch_1", Amount: 60000})
+		require.NoError(t, err)
+
+		_, err = service.GetApproval(ctx, "tenant_2", approval.ID)
+		assert.ErrorIs(t, err, refundguard.ErrApprovalNotFound)
+		_, err = service.ApproveRefund(ctx, approval.ID, refundguard.Actor{TenantID: "tenant_2", APIKeyID: "key_2", OperatorID: "op_2"})
+		assert.ErrorIs(t, err, refundguard.ErrApprovalNotFound)
+		_, err = service.RejectRefund(ctx, approval.ID, refundguard.Actor{TenantID: "tenant_2", APIKeyID: "key_2", OperatorID: "op_2"})
+		assert.ErrorIs(t, err, refundguard.ErrApprovalNotFound)
+		assert.Equal(t, 0, refunder.calls)
+
+		found, err := service.GetApproval(ctx, "tenant_1", approval.ID)
+		require.NoError(t, err)
+		assert.Equal(t, refundguard.ApprovalPending, found.Status)
+	})
+
+	t.Run("should reject refunds outside the policy", func(t *testing.T) {
+		charges := NewMockCompositeChargeLookup(
+			&stripe.Charge{ID: "ch_1", Amount: 10000, AmountRefunded: 4000, Created: time.Now().Add(-time.Hour).Unix()},
+			&stripe.Charge{ID: "ch_old", Amount: 10000, Created: time.Now().Add(-40 * 24 * time.Hour).Unix()},
+		)
+		refunder := &MockRefunder{}
+		service := refundguard.NewService(NewMockRefundGuardStore(), refunder, charges, &MockAlerter{}, limits)
+		service.UsePolicy(&refundguard.Policy{MaxPercent: 50, Window: 30 * 24 * time.Hour})
+
+		_, _, err := service.CreateRefund(ctx, actor, &stripe.RefundRequest{ChargeID: "ch_1", Amount: 1001})
+		assert.ErrorIs(t, err, refundguard.ErrRefundPolicy)
+
+		_, _, err = service.CreateRefund(ctx, actor, &stripe.RefundRequest{ChargeID: "ch_old", Amount: 100})
+		assert.ErrorIs(t, err, refundguard.ErrRefundPolicy)
+		assert.Equal(t, 0, refunder.calls)
+
+		refund, _, err := service.CreateRefund(ctx, actor, &stripe.RefundRequest{ChargeID: "ch_1", Amount: 1000})
+		require.NoError(t, err)
+		assert.NotNil(t, refund)
+	})
+
+	t.Run("should hold refunds over the approval threshold for an approver role", func(t *testing.T) {
+		publisher := &MockEventPublisher{}
+		alerter := &MockAlerter{}
+		refunder := &MockRefunder{}
+		service := refundguard.NewService(NewMockRefundGuardStore(), refunder, nil, alerter, limits)
+		service.UsePolicy(&refundguard.Policy{ApprovalThreshold: 5000, ApproverRoles: []string{"finance"}})
+		source, err := events.NewSource("/payments")
+		require.NoError(t, err)
+		service.UseEmitter(events.NewEmitter(source, publisher))
+
+		_, approval, err := service.CreateRefund(ctx, actor, &stripe.RefundRequest{ChargeID: "ch_1", Amount: 6000})
+		require.NoError(t, err)
+		require.NotNil(t, approval)
+		assert.Equal(t, refundguard.DimensionRefund, approval.Violations[0].Dimension)
+		assert.Empty(t, alerter.alerts, "large refunds are not anomalies")
+		require.Len(t, publisher.events, 1)
+		assert.Equal(t, refundguard.EventApprovalRequested, publisher.events[0].Type)
+		assert.Equal(t, approval.ID, publisher.events[0].Subject)
+
+		_, err = service.ApproveRefund(ctx, approval.ID, refundguard.Actor{TenantID: "tenant_1", OperatorID: "op_2", Roles: []string{"support"}})
+		assert.ErrorIs(t, err, refundguard.ErrApproverRole)
+		assert.Equal(t, 0, refunder.calls)
+
+		approved, err := service.ApproveRefund(ctx, approval.ID, refundguard.Actor{TenantID: "tenant_1", OperatorID: "op_2", Roles: []string{"finance"}})
+		require.NoError(t, err)
+		assert.Equal(t, refundguard.ApprovalApproved, approved.Status)
+		assert.Equal(t, 1, refunder.calls)
+	})
+}
+
+// MockRefundGuardStore is an in-memory refund guard store for testing.
+// Approvals are decided under the same status conditions as the database.
+type MockRefundGuardStore struct {
+	mu        sync.Mutex
+	activity  []*refundguard.Activity
+	approvals map[string]*refundguard.Approval
+}
+
+// NewMockRefundGuardStore creates an empty in-memory refund guard store
+func NewMockRefundGuardStore() *MockRefundGuardStore {
+	return &MockRefundGuardStore{approvals: make(map[string]*refundguard.Approval)}
+}
+
+func (m *MockRefundGuardStore) RecordRefundActivity(ctx context.Context, activity *refundguard.Activity) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	activity.CreatedAt = time.Now()
+	m.activity = append(m.activity, activity)
+	return nil
+}
+
+func (m *MockRefundGuardStore) GetRefundUsage(ctx context.Context, dimension, key string, since time.Time) (refundguard.Usage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var usage refundguard.Usage
+	for _, a := range m.activity {
+		var value string
+		switch dimension {
+		case refundguard.DimensionTenant:
+			value = a.TenantID
+		case refundguard.DimensionAPIKey:
+			value = a.APIKeyID
+		case refundguard.DimensionOperator:
+			value = a.OperatorID
+		}
+		if value == key && !a.CreatedAt.Before(since) {
+			usage.Count++
+			usage.Amount += a.Amount
+		}
+	}
+	return usage, nil
+}
+
+func (m *MockRefundGuardStore) CreateRefundApproval(ctx context.Context, approval *refundguard.Approval) (*refundguard.Approval, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored := *approval
+	m.approvals[approval.ID] = &stored
+	return approval, nil
+}
+
+func (m *MockRefundGuardStore) GetRefundApproval(ctx context.Context, id string) (*refundguard.Approval, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	approval, ok := m.approvals[id]
+	if !ok {
+		return nil, assert.AnError
+	}
+	found := *approval
+	return &found, nil
+}
+
+func (m *MockRefundGuardStore) ListPendingRefundApprovals(ctx context.Context, tenantID string) ([]*refundguard.Approval, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []*refundguard.Approval
+	for _, approval := range m.approvals {
+		if approval.TenantID == tenantID && approval.Status == refundguard.ApprovalPending {
+			found := *approval
+			result = append(result, &found)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockRefundGuardStore) ClaimRefundApproval(ctx context.Context, id, decidedBy string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	approval := m.approvals[id]
+	if approval.Status != refundguard.ApprovalPending {
+		return false, nil
+	}
+	approval.Status = refundguard.ApprovalApproving
+	approval.DecidedBy = decidedBy
+	return true, nil
+}
+
+func (m *MockRefundGuardStore) ReleaseRefundApproval(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if approval := m.approvals[id]; approval.Status == refundguard.ApprovalApproving {
+		approval.Status = refundguard.ApprovalPending
+		approval.DecidedBy = ""
+	}
+	return nil
+}
+
+func (m *MockRefundGuardStore) DecideRefundApproval(ctx context.Context, id, status, decidedBy, refundID string) (*refundguard.Approval, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	approval := m.approvals[id]
+	from := refundguard.ApprovalPending
+	if status == refundguard.ApprovalApproved {
+		from = refundguard.ApprovalApproving
+	}
+	if approval.Status != from {
+		return nil, fmt.Errorf("failed to decide refund approval: %w", sql.ErrNoRows)
+	}
+	approval.Status = status
+	approval.DecidedBy = decidedBy
+	approval.RefundID = refundID
+	decided := *approval
+	return &decided, nil
+}
+
+// MockRefunder counts the refunds it issues and the idempotency keys they
+// carry, or fails them with err
+type MockRefunder struct {
+	mu              sync.Mutex
+	calls           int
+	idempotencyKeys []string
+	err             error
+}
+
+func (m *MockRefunder) CreateRefund(ctx context.Context, request *stripe.RefundRequest) (*stripe.Refund, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.calls++
+	if request.IdempotencyKey != "" {
+		m.idempotencyKeys = append(m.idempotencyKeys, request.IdempotencyKey)
+	}
+	return &stripe.Refund{ID: "re_test", ChargeID: request.ChargeID, Amount: request.Amount, Currency: "usd", Status: "succeeded"}, nil
+}
+
+// MockAlerter records raised alerts
+type MockAlerter struct {
+	alerts []*refundguard.Alert
+	err    error
+}
+
+func (m *MockAlerter) Alert(ctx context.Context, alert *refundguard.Alert) error {
+	m.alerts = append(m.alerts, alert)
+	return m.err
+}
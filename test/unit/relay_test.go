@@ -0,0 +1,362 @@
+package test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"apis/payments/services"
+	"apis/payments/services/batching"
+	"apis/payments/services/events"
+	"apis/payments/services/kafka"
+	"apis/payments/services/relay"
+	"apis/payments/services/stripe"
+	"apis/payments/services/tenancy"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// TestRelay tests re-publishing provider-side changes as CloudEvents
+func TestRelay(t *testing.T) {
+	setup := func(publisher events.Publisher) *stripe.WebhookService {
+		source, err := events.NewSource("/payments")
+		require.NoError(t, err)
+		webhooks := stripe.NewWebhookService("whsec_test")
+		relay.NewService(events.NewEmitter(source, publisher)).RegisterWebhookHandlers(webhooks)
+		return webhooks
+	}
+
+	newEvent := func(id string, eventType stripego.EventType, raw string) stripego.Event {
+		return stripego.Event{
+			ID:      id,
+			Type:    eventType,
+			Created: 1700000100,
+			Data:    &stripego.EventData{Raw: []byte(raw)},
+		}
+	}
+
+	t.Run("should name relayed events after the resource they describe", func(t *testing.T) {
+		assert.Equal(t, "payments.charge.succeeded", relay.EventType(stripego.EventTypeChargeSucceeded))
+		assert.Equal(t, "payments.refund.updated", relay.EventType(stripego.EventTypeChargeRefundUpdated))
+		assert.Equal(t, "payments.refund.created", relay.EventType(stripego.EventTypeRefundCreated))
+		assert.Equal(t, "payments.dispute.funds_withdrawn", relay.EventType(stripego.EventTypeChargeDisputeFundsWithdrawn))
+	})
+
+	t.Run("should publish the normalized object with the provider event's ID and time", func(t *testing.T) {
+		publisher := &MockEventPublisher{}
+		webhooks := setup(publisher)
+
+		require.NoError(t, webhooks.Dispatch(context.Background(), newEvent("evt_1", stripego.EventTypeChargeSucceeded,
+			`{"id": "ch_1", "status": "succeeded", "amount": 1000, "currency": "usd", "customer": {"id": "cus_1"}}`)))
+		require.NoError(t, webhooks.Dispatch(context.Background(), newEvent("evt_2", stripego.EventTypeChargeDisputeCreated,
+			`{"id": "dp_1", "status": "needs_response", "amount": 1000, "charge": {"id": "ch_1"}}`)))
+
+		require.Len(t, publisher.events, 2)
+		event := publisher.events[0]
+		assert.Equal(t, "evt_1", event.ID)
+		assert.Equal(t, "payments.charge.succeeded", event.Type)
+		assert.Equal(t, "ch_1", event.Subject)
+		assert.Equal(t, time.Unix(1700000100, 0).UTC(), event.Time)
+		assert.Contains(t, event.Source, "charges")
+
+		var charge stripe.Charge
+		require.NoError(t, json.Unmarshal(event.Data, &charge))
+		assert.Equal(t, "cus_1", charge.CustomerID)
+		assert.Equal(t, int64(1000), charge.Amount)
+
+		assert.Equal(t, "payments.dispute.created", publisher.events[1].Type)
+		assert.Equal(t, "dp_1", publisher.events[1].Subject)
+	})
+
+	t.Run("should fail the webhook when publishing fails", func(t *testing.T) {
+		producer := &MockSyncProducer{err: errors.New("broker unavailable")}
+		webhooks := setup(kafka.NewPublisherWithProducer(&kafka.Config{EventsTopic: "payment-events"}, producer))
+
+		err := webhooks.Dispatch(context.Background(), newEvent("evt_1", stripego.EventTypeRefundCreated,
+			`{"id": "re_1", "charge": "ch_1", "amount": 500, "status": "succeeded"}`))
+		assert.ErrorContains(t, err, "broker unavailable")
+	})
+
+	t.Run("should publish structured CloudEvents keyed by subject to Kafka", func(t *testing.T) {
+		producer := &MockSyncProducer{}
+		webhooks := setup(kafka.NewPublisherWithProducer(&kafka.Config{EventsTopic: "payment-events"}, producer))
+
+		require.NoError(t, webhooks.Dispatch(context.Background(), newEvent("evt_1", stripego.EventTypeRefundCreated,
+			`{"id": "re_1", "charge": "ch_1", "amount": 500, "status": "succeeded"}`)))
+
+		require.Len(t, producer.sent, 1)
+		message := producer.sent[0]
+		assert.Equal(t, "payment-events", message.Topic)
+		assert.Equal(t, sarama.StringEncoder("re_1"), message.Key)
+		assert.Equal(t, []sarama.RecordHeader{{Key: []byte("content-type"), Value: []byte(kafka.CloudEventsContentType)}}, message.Headers)
+
+		value, err := message.Value.Encode()
+		require.NoError(t, err)
+		var event events.Event
+		require.NoError(t, json.Unmarshal(value, &event))
+		assert.Equal(t, "evt_1", event.ID)
+		assert.Equal(t, "payments.refund.created", event.Type)
+		assert.Equal(t, events.SpecVersion, event.SpecVersion)
+	})
+
+	t.Run("should relay verified Paddle transactions and adjustments", func(t *testing.T) {
+		source, err := events.NewSource("/payments")
+		require.NoError(t, err)
+		publisher := &MockEventPublisher{}
+		gateway, err := services.NewPaddleGateway(map[string]interface{}{
+			"api_key":        "pdl_test",
+			"webhook_secret": "pdl_ntfset_test",
+			"environment":    "sandbox",
+		})
+		require.NoError(t, err)
+		relay.NewService(events.NewEmitter(source, publisher)).RegisterPaddleHandlers(gateway)
+
+		sign := func(payload string) string {
+			timestamp := fmt.Sprint(time.Now().Unix())
+			mac := hmac.New(sha256.New, []byte("pdl_ntfset_test"))
+			mac.Write([]byte(timestamp + ":" + payload))
+			return "ts=" + timestamp + ";h1=" + hex.EncodeToString(mac.Sum(nil))
+		}
+
+		transaction := `{"event_id": "evt_01", "event_type": "transaction.completed", "occurred_at": "2024-05-01T10:00:00Z",
+			"data": {"id": "txn_01", "status": "completed", "currency_code": "USD", "details": {"totals": {"grand_total": "1000"}}}}`
+		_, err = gateway.HandleWebhook(context.Background(), []byte(transaction), sign(transaction))
+		require.NoError(t, err)
+
+		adjustment := `{"event_id": "evt_02", "event_type": "adjustment.created", "occurred_at": "2024-05-01T11:00:00Z",
+			"data": {"id": "adj_01", "action": "refund", "transaction_id": "txn_01", "status": "approved", "currency_code": "USD"}}`
+		_, err = gateway.HandleWebhook(context.Background(), []byte(adjustment), sign(adjustment))
+		require.NoError(t, err)
+
+		_, err = gateway.HandleWebhook(context.Background(), []byte(adjustment), "ts=1;h1=00")
+		assert.Error(t, err)
+
+		require.Len(t, publisher.events, 2)
+		assert.Equal(t, "evt_01", publisher.events[0].ID)
+		assert.Equal(t, "payments.charge.succeeded", publisher.events[0].Type)
+		assert.Equal(t, "txn_01", publisher.events[0].Subject)
+		assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), publisher.events[0].Time)
+		assert.Equal(t, "payments.refund.created", publisher.events[1].Type)
+		assert.Equal(t, "adj_01", publisher.events[1].Subject)
+	})
+
+	t.Run("should relay verified PayPal captures as their order", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			switch r.URL.Path {
+			case "/v1/oauth2/token":
+				fmt.Fprint(w, `{"access_token": "token", "expires_in": 3600}`)
+			case "/v1/notifications/verify-webhook-signature":
+				fmt.Fprint(w, `{"verification_status": "SUCCESS"}`)
+			case "/v2/checkout/orders/ORDER-1":
+				fmt.Fprint(w, `{"id": "ORDER-1", "status": "COMPLETED", "purchase_units": [{"amount": {"currency_code": "USD", "value": "10.00"},
+					"payments": {"captures": [{"id": "CAP-1", "status": "COMPLETED"}]}}]}`)
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		defer server.Close()
+
+		source, err := events.NewSource("/payments")
+		require.NoError(t, err)
+		publisher := &MockEventPublisher{}
+		gateway, err := services.NewPayPalGateway(map[string]interface{}{
+			"client_id":     "client",
+			"client_secret": "secret",
+			"webhook_id":    "WH-1",
+			"environment":   "sandbox",
+			"base_url":      server.URL,
+		})
+		require.NoError(t, err)
+		relay.NewService(events.NewEmitter(source, publisher)).RegisterPayPalHandlers(gateway)
+
+		transmission := services.PayPalTransmission{TransmissionID: "tx_1", TransmissionSig: "sig"}
+		capture := `{"id": "WH-EVT-1", "event_type": "PAYMENT.CAPTURE.COMPLETED", "resource_type": "capture", "create_time": "2024-05-01T10:00:00Z",
+			"resource": {"id": "CAP-1", "status": "COMPLETED", "supplementary_data": {"related_ids": {"order_id": "ORDER-1"}}}}`
+		_, err = gateway.HandleWebhook(context.Background(), []byte(capture), transmission)
+		require.NoError(t, err)
+
+		_, err = gateway.HandleWebhook(context.Background(), []byte(capture), services.PayPalTransmission{})
+		assert.Error(t, err)
+
+		require.Len(t, publisher.events, 1)
+		assert.Equal(t, "WH-EVT-1", publisher.events[0].ID)
+		assert.Equal(t, "payments.charge.succeeded", publisher.events[0].Type)
+		assert.Equal(t, "ORDER-1", publisher.events[0].Subject)
+
+		var charge services.Charge
+		require.NoError(t, json.Unmarshal(publisher.events[0].Data, &charge))
+		assert.Equal(t, int64(1000), charge.Amount)
+	})
+}
+
+// TestRelayOutbox tests storing relayed events and publishing them in
+// adaptive batches
+func TestRelayOutbox(t *testing.T) {
+	ctx := context.Background()
+	enabled := &relay.OutboxConfig{Enabled: true, Interval: time.Second}
+
+	store := func(n int, tenantID string) *MockOutboxStore {
+		store := &MockOutboxStore{}
+		for i := 0; i < n; i++ {
+			store.events = append(store.events, &relay.OutboxEvent{Event: &events.Event{ID: fmt.Sprintf("evt_%03d", i)}, TenantID: tenantID})
+		}
+		return store
+	}
+
+	t.Run("should store relayed events for their tenant instead of publishing them", func(t *testing.T) {
+		source, err := events.NewSource("/payments")
+		require.NoError(t, err)
+		outboxStore := &MockOutboxStore{}
+		publisher := &MockOutboxPublisher{}
+		webhooks := stripe.NewWebhookService("whsec_test")
+		relay.NewService(events.NewEmitter(source, relay.NewOutbox(outboxStore, publisher, enabled))).RegisterWebhookHandlers(webhooks)
+
+		require.NoError(t, webhooks.Dispatch(tenancy.WithTenant(ctx, "acme"), stripego.Event{
+			ID:   "evt_1",
+			Type: stripego.EventTypeChargeSucceeded,
+			Data: &stripego.EventData{Raw: []byte(`{"id": "ch_1", "status": "succeeded"}`)},
+		}))
+
+		assert.Empty(t, publisher.published)
+		require.Len(t, outboxStore.events, 1)
+		assert.Equal(t, "evt_1", outboxStore.events[0].Event.ID)
+		assert.Equal(t, "acme", outboxStore.events[0].TenantID)
+	})
+
+	t.Run("should publish stored events for their tenant and grow batches while publishing keeps up", func(t *testing.T) {
+		outboxStore := store(300, "acme")
+		publisher := &MockOutboxPublisher{}
+		outbox := relay.NewOutbox(outboxStore, publisher, enabled)
+		outbox.UseBatchLimits(&batching.Limits{MinSize: 10, MaxSize: 100, InitialSize: 10, Step: 10, MinConcurrency: 1, MaxConcurrency: 4, TargetLatency: time.Minute, MaxErrorRate: 0.05, Backoff: 0.5})
+
+		published, err := outbox.Flush(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, 300, published)
+		assert.Len(t, publisher.published, 300)
+		assert.Empty(t, outboxStore.events)
+		assert.Equal(t, []string{"acme"}, publisher.tenantList())
+		stats := outbox.BatchStats()
+		assert.Greater(t, stats.Size, 10)
+		assert.Greater(t, stats.Concurrency, 1)
+	})
+
+	t.Run("should keep events that failed to publish and shrink batches", func(t *testing.T) {
+		outboxStore := store(40, tenancy.DefaultTenantID)
+		publisher := &MockOutboxPublisher{fail: map[string]bool{"evt_003": true}}
+		outbox := relay.NewOutbox(outboxStore, publisher, enabled)
+		outbox.UseBatchLimits(&batching.Limits{MinSize: 5, MaxSize: 100, InitialSize: 20, Step: 10, MinConcurrency: 1, MaxConcurrency: 1, TargetLatency: time.Minute, MaxErrorRate: 0.01, Backoff: 0.5})
+
+		published, err := outbox.Flush(ctx)
+
+		assert.ErrorContains(t, err, "1 outbox events failed to publish")
+		assert.Equal(t, 19, published, "publishing stops after the failed batch")
+		require.Len(t, outboxStore.events, 21)
+		assert.Equal(t, "evt_003", outboxStore.events[0].Event.ID)
+		assert.Equal(t, 10, outbox.BatchStats().Size)
+
+		publisher.mu.Lock()
+		publisher.fail = nil
+		publisher.mu.Unlock()
+		published, err = outbox.Flush(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, 21, published)
+		assert.Empty(t, outboxStore.events)
+	})
+
+	t.Run("should stop when stored events can't be read", func(t *testing.T) {
+		outbox := relay.NewOutbox(&MockOutboxStore{err: errors.New("connection refused")}, &MockOutboxPublisher{}, enabled)
+
+		published, err := outbox.Flush(ctx)
+
+		assert.ErrorContains(t, err, "failed to list outbox events")
+		assert.Zero(t, published)
+	})
+}
+
+// MockOutboxStore keeps outbox events in memory, failing listing with err
+type MockOutboxStore struct {
+	mu     sync.Mutex
+	events []*relay.OutboxEvent
+	err    error
+}
+
+func (m *MockOutboxStore) InsertOutboxEvent(ctx context.Context, event *relay.OutboxEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, stored := range m.events {
+		if stored.Event.ID == event.Event.ID {
+			return nil
+		}
+	}
+	m.events = append(m.events, event)
+	return nil
+}
+
+func (m *MockOutboxStore) ListOutboxEvents(ctx context.Context, limit int) ([]*relay.OutboxEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return nil, m.err
+	}
+	return append([]*relay.OutboxEvent(nil), m.events[:min(limit, len(m.events))]...), nil
+}
+
+func (m *MockOutboxStore) DeleteOutboxEvent(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, stored := range m.events {
+		if stored.Event.ID == id {
+			m.events = append(m.events[:i], m.events[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// MockOutboxPublisher records published events and the tenants they were
+// published for, failing the events in fail
+type MockOutboxPublisher struct {
+	mu        sync.Mutex
+	published []*events.Event
+	tenants   map[string]bool
+	fail      map[string]bool
+}
+
+func (m *MockOutboxPublisher) Publish(ctx context.Context, event *events.Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.fail[event.ID] {
+		return errors.New("broker unavailable")
+	}
+	m.published = append(m.published, event)
+	if m.tenants == nil {
+		m.tenants = map[string]bool{}
+	}
+	m.tenants[tenancy.ID(ctx)] = true
+	return nil
+}
+
+func (m *MockOutboxPublisher) tenantList() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var tenants []string
+	for tenantID := range m.tenants {
+		tenants = append(tenants, tenantID)
+	}
+	return tenants
+}
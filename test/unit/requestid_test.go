@@ -0,0 +1,96 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"apis/payments/services/events"
+	"apis/payments/services/requestid"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// TestRequestID tests assigning request IDs and carrying them into spans,
+// log lines and published events
+func TestRequestID(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should keep valid caller IDs and replace malformed ones", func(t *testing.T) {
+		assert.Equal(t, "req-123", requestid.Resolve("req-123"))
+
+		for _, id := range []string{"", "has space", "line\nbreak", strings.Repeat("a", 129)} {
+			assert.False(t, requestid.Valid(id), id)
+			generated := requestid.Resolve(id)
+			assert.NotEqual(t, id, generated)
+			assert.True(t, requestid.Valid(generated))
+		}
+	})
+
+	t.Run("should carry the ID through contexts and bound requests", func(t *testing.T) {
+		_, ok := requestid.FromContext(ctx)
+		assert.False(t, ok)
+
+		id, ok := requestid.FromContext(requestid.WithID(ctx, "req-ctx"))
+		assert.True(t, ok)
+		assert.Equal(t, "req-ctx", id)
+
+		request := &fasthttp.RequestCtx{}
+		requestid.Bind(request, "req-bound")
+		id, ok = requestid.FromContext(request)
+		assert.True(t, ok)
+		assert.Equal(t, "req-bound", id)
+	})
+
+	t.Run("should tag spans started within a request", func(t *testing.T) {
+		recorder := tracetest.NewSpanRecorder()
+		provider := sdktrace.NewTracerProvider(
+			sdktrace.WithSpanProcessor(requestid.SpanProcessor{}),
+			sdktrace.WithSpanProcessor(recorder),
+		)
+		tracer := provider.Tracer("test")
+
+		_, tagged := tracer.Start(requestid.WithID(ctx, "req-span"), "Tagged")
+		tagged.End()
+		_, untagged := tracer.Start(ctx, "Untagged")
+		untagged.End()
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 2)
+		assert.Contains(t, spans[0].Attributes(), attribute.String(requestid.Attribute, "req-span"))
+		for _, attr := range spans[1].Attributes() {
+			assert.NotEqual(t, attribute.Key(requestid.Attribute), attr.Key)
+		}
+	})
+
+	t.Run("should prefix log lines with the ID", func(t *testing.T) {
+		var buffer bytes.Buffer
+		log.SetOutput(&buffer)
+		defer log.SetOutput(os.Stderr)
+
+		requestid.Logf(requestid.WithID(ctx, "req-log"), "charge %s failed", "ch_1")
+		assert.Contains(t, buffer.String(), "[req-log] charge ch_1 failed")
+	})
+
+	t.Run("should add the requestid extension to emitted events", func(t *testing.T) {
+		source, err := events.NewSource("/payments")
+		require.NoError(t, err)
+		publisher := &MockEventPublisher{}
+		emitter := events.NewEmitter(source, publisher)
+
+		require.NoError(t, emitter.Emit(requestid.WithID(ctx, "req-event"), "refunds", "payments.refund.created", "re_1", map[string]string{"id": "re_1"}))
+		require.NoError(t, emitter.Emit(ctx, "refunds", "payments.refund.created", "re_2", map[string]string{"id": "re_2"}))
+
+		require.Len(t, publisher.events, 2)
+		assert.Equal(t, "req-event", publisher.events[0].RequestID)
+		assert.Empty(t, publisher.events[1].RequestID)
+	})
+}
@@ -0,0 +1,166 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"apis/payments/services"
+	"apis/payments/services/resilience"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// TestResilience tests retrying, timing out and circuit breaking gateway calls
+func TestResilience(t *testing.T) {
+	ctx := context.Background()
+	unavailable := &stripego.Error{HTTPStatusCode: http.StatusServiceUnavailable, Msg: "unavailable"}
+	declined := &stripego.Error{HTTPStatusCode: http.StatusPaymentRequired, Code: stripego.ErrorCodeCardDeclined}
+
+	setup := func(maxAttempts int, errs ...error) (services.PaymentGateway, *MockFlakyGateway, *resilience.Policy) {
+		policy := services.NewResiliencePolicy(&resilience.Config{
+			Enabled:          true,
+			MaxAttempts:      maxAttempts,
+			BaseDelay:        time.Millisecond,
+			MaxDelay:         2 * time.Millisecond,
+			AttemptTimeout:   50 * time.Millisecond,
+			Budget:           time.Second,
+			FailureThreshold: 3,
+			OpenDuration:     30 * time.Millisecond,
+		})
+		flaky := &MockFlakyGateway{errs: errs}
+		return services.NewResilientGateway(flaky, policy), flaky, policy
+	}
+
+	t.Run("should retry transient failures of reads", func(t *testing.T) {
+		gateway, flaky, policy := setup(3, unavailable, unavailable)
+
+		charge, err := gateway.GetCharge(ctx, "ch_1")
+		require.NoError(t, err)
+		assert.Equal(t, "ch_1", charge.ID)
+		assert.Equal(t, 3, flaky.calls)
+		assert.Equal(t, resilience.StateClosed, policy.Breakers()[0].State)
+	})
+
+	t.Run("should not retry creates or non-transient errors", func(t *testing.T) {
+		gateway, flaky, _ := setup(3, unavailable)
+		_, err := gateway.CreateCharge(ctx, services.CreateChargeRequest{Amount: 1000, Currency: "usd"})
+		assert.ErrorIs(t, err, unavailable)
+		assert.Equal(t, 1, flaky.calls)
+
+		gateway, flaky, policy := setup(3, declined)
+		_, err = gateway.GetCharge(ctx, "ch_1")
+		assert.ErrorIs(t, err, declined)
+		assert.Equal(t, 1, flaky.calls)
+		assert.Equal(t, 0, policy.Breakers()[0].ConsecutiveFailures)
+	})
+
+	t.Run("should open the breaker after consecutive failures and close it after a probe", func(t *testing.T) {
+		gateway, flaky, policy := setup(1, unavailable, unavailable, unavailable)
+
+		for i := 0; i < 3; i++ {
+			_, err := gateway.GetCharge(ctx, "ch_1")
+			assert.ErrorIs(t, err, unavailable)
+		}
+		assert.Equal(t, []string{"flaky.GetCharge"}, policy.Open())
+
+		_, err := gateway.GetCharge(ctx, "ch_1")
+		assert.ErrorIs(t, err, resilience.ErrCircuitOpen)
+		assert.Equal(t, 3, flaky.calls, "open breakers do not call the provider")
+
+		_, err = gateway.ListCharges(ctx, services.ListChargesRequest{})
+		assert.NoError(t, err, "breakers are per operation")
+
+		time.Sleep(40 * time.Millisecond)
+		assert.Equal(t, resilience.StateHalfOpen, policy.Breakers()[0].State)
+
+		_, err = gateway.GetCharge(ctx, "ch_1")
+		require.NoError(t, err)
+		assert.Empty(t, policy.Open())
+	})
+
+	t.Run("should reopen the breaker when the probe fails", func(t *testing.T) {
+		gateway, _, policy := setup(1, unavailable, unavailable, unavailable, unavailable)
+		for i := 0; i < 3; i++ {
+			gateway.GetCharge(ctx, "ch_1")
+		}
+
+		time.Sleep(40 * time.Millisecond)
+		_, err := gateway.GetCharge(ctx, "ch_1")
+		assert.ErrorIs(t, err, unavailable)
+		assert.Equal(t, resilience.StateOpen, policy.Breakers()[0].State)
+	})
+
+	t.Run("should time out slow attempts", func(t *testing.T) {
+		gateway, flaky, _ := setup(2)
+		flaky.hang = true
+
+		start := time.Now()
+		_, err := gateway.GetCharge(ctx, "ch_1")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, 2, flaky.calls)
+		assert.Less(t, time.Since(start), 500*time.Millisecond)
+	})
+
+	t.Run("should classify transient errors", func(t *testing.T) {
+		assert.True(t, services.IsTransientError(unavailable))
+		assert.True(t, services.IsTransientError(&stripego.Error{HTTPStatusCode: http.StatusTooManyRequests}))
+		assert.True(t, services.IsTransientError(&net.OpError{Op: "dial", Err: errors.New("connection refused")}))
+		assert.True(t, services.IsTransientError(context.DeadlineExceeded))
+		assert.False(t, services.IsTransientError(declined))
+		assert.False(t, services.IsTransientError(errors.New("invalid request")))
+
+		_, ok := services.NewResilientGateway(&MockFlakyGateway{}, services.NewResiliencePolicy(nil)).(services.DisputeManager)
+		assert.False(t, ok, "dispute support follows the wrapped gateway")
+	})
+}
+
+// MockFlakyGateway fails calls with scripted errors before succeeding, or
+// hangs until the call's context ends
+type MockFlakyGateway struct {
+	services.PaymentGateway
+	errs  []error
+	hang  bool
+	calls int
+}
+
+func (m *MockFlakyGateway) GetProvider() string {
+	return "flaky"
+}
+
+func (m *MockFlakyGateway) next(ctx context.Context) error {
+	m.calls++
+	if m.hang {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	if len(m.errs) > 0 {
+		err := m.errs[0]
+		m.errs = m.errs[1:]
+		return err
+	}
+	return nil
+}
+
+func (m *MockFlakyGateway) GetCharge(ctx context.Context, chargeID string) (*services.Charge, error) {
+	if err := m.next(ctx); err != nil {
+		return nil, err
+	}
+	return &services.Charge{ID: chargeID}, nil
+}
+
+func (m *MockFlakyGateway) ListCharges(ctx context.Context, req services.ListChargesRequest) (*services.ChargeList, error) {
+	return &services.ChargeList{}, nil
+}
+
+func (m *MockFlakyGateway) CreateCharge(ctx context.Context, req services.CreateChargeRequest) (*services.Charge, error) {
+	if err := m.next(ctx); err != nil {
+		return nil, err
+	}
+	return &services.Charge{ID: "ch_new", Amount: req.Amount}, nil
+}
@@ -0,0 +1,148 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"apis/payments/services"
+	"apis/payments/services/routing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCurrencyRouting tests routing charges to local providers by currency
+// or to the provider their request selects
+func TestCurrencyRouting(t *testing.T) {
+	setup := func() (*routing.Router, *MockRoutingStore) {
+		store := &MockRoutingStore{}
+		router := routing.NewRouter(store, &routing.Config{
+			DefaultProvider:      "stripe",
+			CurrencyRoutes:       map[string]string{"eur": "adyen", "brl": "ebanx"},
+			SettlementCurrencies: map[string]string{"stripe": "usd", "adyen": "eur"},
+			BaseCurrency:         "usd",
+			Rates:                map[string]float64{"eur": 1.1, "jpy": 0.0067},
+		})
+		router.RegisterProvider("stripe")
+		router.RegisterProvider("adyen")
+		return router, store
+	}
+
+	t.Run("should route a currency to its configured provider", func(t *testing.T) {
+		router, _ := setup()
+
+		decision := router.Select("EUR")
+		assert.Equal(t, "adyen", decision.Provider)
+		assert.Equal(t, "eur", decision.SettlementCurrency)
+		assert.Equal(t, routing.ReasonCurrency, decision.Reason)
+	})
+
+	t.Run("should fall back when the routed provider is not configured", func(t *testing.T) {
+		router, _ := setup()
+
+		decision := router.Select("brl")
+		assert.Equal(t, "stripe", decision.Provider)
+		assert.Equal(t, routing.ReasonFallback, decision.Reason)
+	})
+
+	t.Run("should use the default provider for unrouted currencies", func(t *testing.T) {
+		router, _ := setup()
+
+		decision := router.Select("gbp")
+		assert.Equal(t, "stripe", decision.Provider)
+		assert.Equal(t, "usd", decision.SettlementCurrency)
+		assert.Equal(t, routing.ReasonDefault, decision.Reason)
+	})
+
+	t.Run("should route charges to the provider their request selects", func(t *testing.T) {
+		router, _ := setup()
+
+		decision := router.Route(context.Background(), "eur")
+		assert.Equal(t, "adyen", decision.Provider)
+		assert.Equal(t, routing.ReasonCurrency, decision.Reason)
+
+		decision = router.Route(routing.WithProvider(context.Background(), "stripe"), "EUR")
+		assert.Equal(t, "stripe", decision.Provider)
+		assert.Equal(t, "eur", decision.Currency)
+		assert.Equal(t, "usd", decision.SettlementCurrency)
+		assert.Equal(t, routing.ReasonRequested, decision.Reason)
+	})
+
+	t.Run("should only select enabled providers", func(t *testing.T) {
+		providers := services.NewEnabledProviders([]string{"stripe", " Paddle"})
+		assert.Equal(t, []string{"paddle", "stripe"}, providers.List())
+
+		provider, err := providers.Resolve("PADDLE")
+		require.NoError(t, err)
+		assert.Equal(t, "paddle", provider)
+
+		provider, err = providers.Resolve("")
+		require.NoError(t, err)
+		assert.Empty(t, provider)
+
+		_, err = providers.Resolve("square")
+		assert.ErrorIs(t, err, services.ErrProviderNotEnabled)
+	})
+
+	t.Run("should settle in the charge currency when none is configured", func(t *testing.T) {
+		router, _ := setup()
+		assert.Equal(t, "brl", router.SettlementCurrency("ebanx", "brl"))
+	})
+
+	t.Run("should convert between currencies with different exponents", func(t *testing.T) {
+		router, _ := setup()
+
+		amount, ok := router.ToBase(1000, "eur")
+		require.True(t, ok)
+		assert.Equal(t, int64(1100), amount)
+
+		amount, ok = router.ToBase(10000, "jpy")
+		require.True(t, ok)
+		assert.Equal(t, int64(6700), amount)
+
+		_, ok = router.ToBase(1000, "brl")
+		assert.False(t, ok)
+	})
+
+	t.Run("should consolidate provider volume in the base currency", func(t *testing.T) {
+		router, store := setup()
+		store.volumes = []*routing.Volume{
+			{Provider: "adyen", Currency: "eur", SettlementCurrency: "eur", Count: 2, Amount: 2000},
+			{Provider: "stripe", Currency: "usd", SettlementCurrency: "usd", Count: 1, Amount: 500},
+			{Provider: "stripe", Currency: "brl", SettlementCurrency: "usd", Count: 1, Amount: 900},
+		}
+
+		report, err := router.Report(context.Background(), time.Now().Add(-time.Hour), time.Now())
+		require.NoError(t, err)
+		require.Len(t, report.Providers, 2)
+		assert.Equal(t, int64(2200), report.Providers[0].BaseAmount)
+		assert.Equal(t, int64(500), report.Providers[1].BaseAmount)
+		assert.Equal(t, int64(2700), report.BaseAmount)
+		assert.Equal(t, []string{"brl"}, report.UnconvertedCurrencies)
+	})
+
+	t.Run("should record the decision for a charge", func(t *testing.T) {
+		router, store := setup()
+
+		require.NoError(t, router.Record(context.Background(), "ch_1", 2000, router.Select("eur")))
+		require.Len(t, store.recorded, 1)
+		assert.Equal(t, "adyen", store.recorded[0].Provider)
+		assert.Equal(t, int64(2000), store.recorded[0].Amount)
+	})
+}
+
+// MockRoutingStore records routed charges and serves canned volume
+type MockRoutingStore struct {
+	recorded []*routing.RoutedCharge
+	volumes  []*routing.Volume
+}
+
+func (m *MockRoutingStore) RecordRoutedCharge(ctx context.Context, charge *routing.RoutedCharge) error {
+	m.recorded = append(m.recorded, charge)
+	return nil
+}
+
+func (m *MockRoutingStore) SummarizeRoutedCharges(ctx context.Context, from, to time.Time) ([]*routing.Volume, error) {
+	return m.volumes, nil
+}
@@ -0,0 +1,46 @@
+package test
+
+import (
+	"testing"
+
+	"apis/payments/services/runmode"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRunMode tests parsing the role an instance runs as
+func TestRunMode(t *testing.T) {
+	t.Run("should default to running every role", func(t *testing.T) {
+		mode, err := runmode.Parse("")
+		require.NoError(t, err)
+		assert.Equal(t, runmode.All, mode)
+		assert.True(t, mode.ServesAPI())
+		assert.True(t, mode.RunsWorkers())
+	})
+
+	t.Run("should separate the api and worker roles", func(t *testing.T) {
+		api, err := runmode.Parse("API")
+		require.NoError(t, err)
+		assert.True(t, api.ServesAPI())
+		assert.False(t, api.RunsWorkers())
+
+		worker, err := runmode.Parse(" worker ")
+		require.NoError(t, err)
+		assert.False(t, worker.ServesAPI())
+		assert.True(t, worker.RunsWorkers())
+	})
+
+	t.Run("should reject unknown modes", func(t *testing.T) {
+		_, err := runmode.Parse("scheduler")
+		assert.ErrorIs(t, err, runmode.ErrInvalidMode)
+	})
+
+	t.Run("should read RUN_MODE", func(t *testing.T) {
+		t.Setenv("RUN_MODE", "worker")
+
+		mode, err := runmode.Load()
+		require.NoError(t, err)
+		assert.Equal(t, runmode.Worker, mode)
+	})
+}
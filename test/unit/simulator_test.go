@@ -0,0 +1,301 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"apis/payments/services"
+	"apis/payments/services/simulator"
+	"apis/payments/services/tenancy"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSimulator tests the simulated provider: test card outcomes, 3D Secure,
+// disputes, latency injection and per-tenant persistence
+func TestSimulator(t *testing.T) {
+	ctx := tenancy.WithTenant(context.Background(), "tenant_1")
+	setup := func() (*services.SimulatorGateway, *MockSimulatorStore) {
+		store := NewMockSimulatorStore()
+		return services.NewSimulatorGateway(simulator.New(store, &simulator.Config{Enabled: true})), store
+	}
+	charge := func(gateway *services.SimulatorGateway, number string, capture bool) (*services.Charge, error) {
+		return gateway.CreateCharge(ctx, services.CreateChargeRequest{Amount: 2000, Currency: "usd", PaymentMethodID: number, Capture: capture})
+	}
+
+	t.Run("should succeed charges with succeeding test cards", func(t *testing.T) {
+		gateway, _ := setup()
+
+		result, err := charge(gateway, "4242 4242 4242 4242", true)
+		require.NoError(t, err)
+		assert.Equal(t, "succeeded", result.Status)
+		assert.Equal(t, simulator.Provider, result.Provider)
+
+		// Other numbers passing the Luhn check succeed too
+		result, err = charge(gateway, "4111111111111111", true)
+		require.NoError(t, err)
+		assert.Equal(t, "succeeded", result.Status)
+	})
+
+	t.Run("should decline charges with the test card's decline code", func(t *testing.T) {
+		gateway, _ := setup()
+
+		_, err := charge(gateway, "4000000000009995", true)
+		var simulatorErr *simulator.Error
+		require.True(t, errors.As(err, &simulatorErr))
+		assert.Equal(t, simulator.CodeCardDeclined, simulatorErr.Code)
+		assert.Equal(t, "insufficient_funds", simulatorErr.DeclineCode)
+		assert.Equal(t, http.StatusPaymentRequired, simulatorErr.HTTPStatus())
+		assert.False(t, services.IsFailoverError(err))
+
+		list, err := gateway.ListCharges(ctx, services.ListChargesRequest{Status: "failed"})
+		require.NoError(t, err)
+		assert.Len(t, list.Charges, 1)
+	})
+
+	t.Run("should fail processing errors over to another provider", func(t *testing.T) {
+		gateway, _ := setup()
+
+		_, err := charge(gateway, "4000000000000119", true)
+		assert.True(t, services.IsFailoverError(err))
+	})
+
+	t.Run("should reject invalid card numbers", func(t *testing.T) {
+		gateway, _ := setup()
+
+		_, err := charge(gateway, "4242424242424241", true)
+		var simulatorErr *simulator.Error
+		require.True(t, errors.As(err, &simulatorErr))
+		assert.Equal(t, simulator.CodeInvalidNumber, simulatorErr.Code)
+		assert.Equal(t, http.StatusBadRequest, simulatorErr.HTTPStatus())
+	})
+
+	t.Run("should hold 3D Secure charges until they are authenticated", func(t *testing.T) {
+		gateway, _ := setup()
+
+		result, err := charge(gateway, "4000000000003220", true)
+		require.NoError(t, err)
+		assert.Equal(t, "requires_action", result.Status)
+
+		authenticated, err := gateway.AuthenticateCharge(ctx, result.ID, true)
+		require.NoError(t, err)
+		assert.Equal(t, "succeeded", authenticated.Status)
+
+		_, err = gateway.AuthenticateCharge(ctx, result.ID, true)
+		assert.Error(t, err)
+
+		result, err = charge(gateway, "4000000000003220", true)
+		require.NoError(t, err)
+		failed, err := gateway.AuthenticateCharge(ctx, result.ID, false)
+		require.NoError(t, err)
+		assert.Equal(t, "failed", failed.Status)
+	})
+
+	t.Run("should authorize, capture and refund charges", func(t *testing.T) {
+		gateway, _ := setup()
+
+		result, err := charge(gateway, "4242424242424242", false)
+		require.NoError(t, err)
+		assert.Equal(t, "requires_capture", result.Status)
+		require.NotNil(t, result.CaptureBefore)
+
+		captured, err := gateway.CaptureCharge(ctx, result.ID, services.CaptureChargeRequest{Amount: 1500})
+		require.NoError(t, err)
+		assert.Equal(t, "succeeded", captured.Status)
+
+		_, err = gateway.CreateRefund(ctx, services.CreateRefundRequest{ChargeID: result.ID, Amount: 2000})
+		assert.Error(t, err, "only what was captured can be refunded")
+
+		refund, err := gateway.CreateRefund(ctx, services.CreateRefundRequest{ChargeID: result.ID, Amount: 1000})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1000), refund.Amount)
+
+		refund, err = gateway.CreateRefund(ctx, services.CreateRefundRequest{ChargeID: result.ID})
+		require.NoError(t, err)
+		assert.Equal(t, int64(500), refund.Amount)
+	})
+
+	t.Run("should dispute charges made with disputed test cards", func(t *testing.T) {
+		gateway, _ := setup()
+
+		result, err := charge(gateway, "4000000000000259", true)
+		require.NoError(t, err)
+
+		disputes, err := gateway.ListDisputes(ctx, services.ListDisputesRequest{ChargeID: result.ID})
+		require.NoError(t, err)
+		require.Len(t, disputes.Disputes, 1)
+		dispute := disputes.Disputes[0]
+		assert.Equal(t, "needs_response", dispute.Status)
+		assert.Equal(t, "fraudulent", dispute.Reason)
+		assert.Equal(t, int64(2000), dispute.Amount)
+
+		won, err := gateway.SubmitDisputeEvidence(ctx, dispute.ID, services.SubmitDisputeEvidenceRequest{
+			Evidence: []services.DisputeEvidence{{Type: "REBUTTAL_EXPLANATION", Text: "winning_evidence"}},
+			Submit:   true,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "won", won.Status)
+
+		_, err = gateway.AcceptDispute(ctx, dispute.ID)
+		assert.Error(t, err, "decided disputes cannot be answered")
+	})
+
+	t.Run("should open inquiries only once charges are captured", func(t *testing.T) {
+		gateway, _ := setup()
+
+		result, err := charge(gateway, "4000000000001976", false)
+		require.NoError(t, err)
+		disputes, err := gateway.ListDisputes(ctx, services.ListDisputesRequest{ChargeID: result.ID})
+		require.NoError(t, err)
+		assert.Empty(t, disputes.Disputes)
+
+		_, err = gateway.CaptureCharge(ctx, result.ID, services.CaptureChargeRequest{})
+		require.NoError(t, err)
+		disputes, err = gateway.ListDisputes(ctx, services.ListDisputesRequest{ChargeID: result.ID})
+		require.NoError(t, err)
+		require.Len(t, disputes.Disputes, 1)
+		assert.Equal(t, "warning_needs_response", disputes.Disputes[0].Status)
+
+		closed, err := gateway.AcceptDispute(ctx, disputes.Disputes[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, "warning_closed", closed.Status)
+	})
+
+	t.Run("should charge saved cards without returning their number", func(t *testing.T) {
+		gateway, _ := setup()
+
+		customer, err := gateway.CreateCustomer(ctx, services.CreateCustomerRequest{Email: "jane@example.com"})
+		require.NoError(t, err)
+		method, err := gateway.AddPaymentMethod(ctx, customer.ID, services.AddPaymentMethodRequest{
+			Type:     "card",
+			Metadata: map[string]interface{}{"number": "4000000000000002", "nickname": "work"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "0002", method.Card.Last4)
+		assert.NotContains(t, method.Metadata, "number")
+		assert.Equal(t, "work", method.Metadata["nickname"])
+
+		_, err = gateway.CreateCharge(ctx, services.CreateChargeRequest{Amount: 500, Currency: "usd", CustomerID: customer.ID, PaymentMethodID: method.ID, Capture: true})
+		var simulatorErr *simulator.Error
+		require.True(t, errors.As(err, &simulatorErr))
+		assert.Equal(t, "generic_decline", simulatorErr.DeclineCode)
+	})
+
+	t.Run("should keep each tenant's objects to itself", func(t *testing.T) {
+		gateway, _ := setup()
+
+		customer, err := gateway.CreateCustomer(ctx, services.CreateCustomerRequest{Email: "jane@example.com"})
+		require.NoError(t, err)
+
+		found, err := gateway.GetCustomer(ctx, customer.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "jane@example.com", found.Email)
+
+		_, err = gateway.GetCustomer(tenancy.WithTenant(context.Background(), "tenant_2"), customer.ID)
+		var simulatorErr *simulator.Error
+		require.True(t, errors.As(err, &simulatorErr))
+		assert.Equal(t, http.StatusNotFound, simulatorErr.HTTPStatus())
+	})
+
+	t.Run("should page lists newest first", func(t *testing.T) {
+		gateway, _ := setup()
+
+		for i := 0; i < 3; i++ {
+			_, err := charge(gateway, "4242424242424242", true)
+			require.NoError(t, err)
+		}
+
+		first, err := gateway.ListCharges(ctx, services.ListChargesRequest{Limit: 2})
+		require.NoError(t, err)
+		assert.Len(t, first.Charges, 2)
+		assert.True(t, first.HasMore)
+
+		rest, err := gateway.ListCharges(ctx, services.ListChargesRequest{Limit: 2, Cursor: first.NextCursor})
+		require.NoError(t, err)
+		assert.Len(t, rest.Charges, 1)
+		assert.False(t, rest.HasMore)
+	})
+
+	t.Run("should delay calls by the injected latency", func(t *testing.T) {
+		gateway, _ := setup()
+
+		require.NoError(t, gateway.SetLatency(simulator.Latency{LatencyMS: 50}))
+		assert.Equal(t, int64(50), gateway.Latency().LatencyMS)
+
+		start := time.Now()
+		_, err := charge(gateway, "4242424242424242", true)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+		timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, err = gateway.CreateCharge(timeoutCtx, services.CreateChargeRequest{Amount: 2000, Currency: "usd", PaymentMethodID: "4242424242424242", Capture: true})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		assert.Error(t, gateway.SetLatency(simulator.Latency{LatencyMS: -1}))
+	})
+}
+
+// MockSimulatorStore keeps simulator objects in memory, in the order they
+// were first saved
+type MockSimulatorStore struct {
+	objects map[string]*simulator.Object
+	order   []string
+}
+
+func NewMockSimulatorStore() *MockSimulatorStore {
+	return &MockSimulatorStore{objects: make(map[string]*simulator.Object)}
+}
+
+func (m *MockSimulatorStore) SaveSimulatorObject(ctx context.Context, object *simulator.Object) error {
+	key := object.TenantID + "/" + object.ID
+	if existing, ok := m.objects[key]; ok {
+		object.CreatedAt = existing.CreatedAt
+	} else {
+		object.CreatedAt = time.Now()
+		m.order = append(m.order, key)
+	}
+	m.objects[key] = object
+	return nil
+}
+
+func (m *MockSimulatorStore) GetSimulatorObject(ctx context.Context, tenantID, kind, id string) (*simulator.Object, error) {
+	object, ok := m.objects[tenantID+"/"+id]
+	if !ok || object.Kind != kind {
+		return nil, sql.ErrNoRows
+	}
+	return object, nil
+}
+
+func (m *MockSimulatorStore) ListSimulatorObjects(ctx context.Context, tenantID, kind, parentID, cursor string, limit int) ([]*simulator.Object, error) {
+	var objects []*simulator.Object
+	afterCursor := cursor == ""
+	for i := len(m.order) - 1; i >= 0; i-- {
+		object, ok := m.objects[m.order[i]]
+		if !ok || object.TenantID != tenantID || object.Kind != kind || (parentID != "" && object.ParentID != parentID) {
+			continue
+		}
+		if !afterCursor {
+			afterCursor = object.ID == cursor
+			continue
+		}
+		if len(objects) < limit {
+			objects = append(objects, object)
+		}
+	}
+	return objects, nil
+}
+
+func (m *MockSimulatorStore) DeleteSimulatorObject(ctx context.Context, tenantID, kind, id string) (bool, error) {
+	key := tenantID + "/" + id
+	if object, ok := m.objects[key]; !ok || object.Kind != kind {
+		return false, nil
+	}
+	delete(m.objects, key)
+	return true, nil
+}
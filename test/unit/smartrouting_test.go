@@ -0,0 +1,226 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"apis/payments/services"
+	"apis/payments/services/resilience"
+	"apis/payments/services/routing"
+	"apis/payments/services/smartrouting"
+	"apis/payments/services/tenancy"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSmartRouting tests ranking providers by cost and authorization rate
+// with rule overrides
+func TestSmartRouting(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(strategy string, rates ...*smartrouting.AuthRate) (*smartrouting.Service, *MockSmartRoutingStore, *MockRoutingStats) {
+		fees, err := smartrouting.ParseFees("stripe=290:30:150, adyen=260:12:100, square=350:0")
+		require.NoError(t, err)
+		store := &MockSmartRoutingStore{rules: make(map[string]*smartrouting.Rule)}
+		stats := &MockRoutingStats{rates: rates}
+		service := smartrouting.NewService(store, stats, &smartrouting.Config{
+			Enabled:         true,
+			Strategy:        strategy,
+			Fees:            fees,
+			HomeCountries:   map[string]string{"stripe": "us", "adyen": "nl"},
+			MinSamples:      100,
+			Window:          24 * time.Hour,
+			RefreshInterval: time.Minute,
+		})
+		return service, store, stats
+	}
+
+	t.Run("should parse fees and price charges", func(t *testing.T) {
+		fees, err := smartrouting.ParseFees("stripe=290:30")
+		require.NoError(t, err)
+		assert.Equal(t, smartrouting.Fee{PercentBps: 290, Fixed: 30}, fees["stripe"])
+		assert.Equal(t, int64(320), fees["stripe"].Cost(10000, false))
+		assert.Equal(t, int64(470), smartrouting.Fee{PercentBps: 290, Fixed: 30, CrossBorderBps: 150}.Cost(10000, true))
+
+		for _, raw := range []string{"stripe", "stripe=290", "stripe=a:30", "stripe=1:2:3:4", "stripe=-1:0"} {
+			_, err := smartrouting.ParseFees(raw)
+			assert.Error(t, err, raw)
+		}
+	})
+
+	t.Run("should rank the cheapest provider first, counting cross-border surcharges", func(t *testing.T) {
+		service, _, _ := setup(smartrouting.StrategyCost)
+
+		decision, err := service.Decide(ctx, smartrouting.Charge{Amount: 10000, Currency: "eur", BINCountry: "nl"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"adyen", "square", "stripe"}, decision.Providers)
+		assert.Equal(t, int64(272), decision.Scores[0].Fee)
+		assert.Equal(t, int64(470), decision.Scores[2].Fee, "stripe charges cross-border for Dutch cards")
+
+		decision, err = service.Decide(ctx, smartrouting.Charge{Amount: 10000, Currency: "usd", BINCountry: "us"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"stripe", "square", "adyen"}, decision.Providers)
+	})
+
+	t.Run("should rank by authorization rate, falling back to broader rates", func(t *testing.T) {
+		service, _, _ := setup(smartrouting.StrategyAuthRate,
+			&smartrouting.AuthRate{Provider: "stripe", Currency: "eur", BINCountry: "de", Attempts: 200, Authorized: 170},
+			&smartrouting.AuthRate{Provider: "adyen", Currency: "eur", BINCountry: "de", Attempts: 20, Authorized: 20},
+			&smartrouting.AuthRate{Provider: "adyen", Currency: "eur", BINCountry: "fr", Attempts: 180, Authorized: 162},
+		)
+
+		decision, err := service.Decide(ctx, smartrouting.Charge{Amount: 5000, Currency: "eur", BINCountry: "de"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"adyen", "stripe", "square"}, decision.Providers)
+		assert.Equal(t, smartrouting.ScopeCurrency, decision.Scores[0].RateScope, "20 attempts in DE are too few")
+		assert.InDelta(t, 0.91, decision.Scores[0].AuthRate, 0.001)
+		assert.Equal(t, smartrouting.ScopeCountry, decision.Scores[1].RateScope)
+		assert.Equal(t, smartrouting.ScopeNone, decision.Scores[2].RateScope, "providers without history rank last")
+	})
+
+	t.Run("should apply the first enabled matching rule", func(t *testing.T) {
+		service, store, _ := setup(smartrouting.StrategyCost)
+
+		_, err := service.CreateRule(ctx, &smartrouting.Rule{Currency: "USD", PinnedProvider: "square", Enabled: false, Priority: 1})
+		require.NoError(t, err)
+		rule, err := service.CreateRule(ctx, &smartrouting.Rule{Currency: "USD", MinAmount: 50000, Providers: []string{"Square", "Stripe"}, Enabled: true, Priority: 10})
+		require.NoError(t, err)
+		assert.Equal(t, "usd", rule.Currency)
+
+		decision, err := service.Decide(ctx, smartrouting.Charge{Amount: 100000, Currency: "usd", BINCountry: "us"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, rule.ID, decision.RuleID)
+		assert.Equal(t, []string{"stripe", "square"}, decision.Providers)
+
+		rule.PinnedProvider = "square"
+		_, err = service.UpdateRule(ctx, rule)
+		require.NoError(t, err)
+		decision, err = service.Decide(ctx, smartrouting.Charge{Amount: 100000, Currency: "usd"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"square", "stripe"}, decision.Providers, "rule changes apply at once")
+
+		decision, err = service.Decide(ctx, smartrouting.Charge{Amount: 100000, Currency: "usd"}, []string{"adyen", "stripe"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"stripe"}, decision.Providers, "given candidates are narrowed, never added to")
+		assert.Len(t, store.rules, 2)
+	})
+
+	t.Run("should validate rules and report missing ones", func(t *testing.T) {
+		service, _, _ := setup(smartrouting.StrategyCost)
+
+		for _, rule := range []*smartrouting.Rule{
+			{Currency: "usd"},
+			{Currency: "dollars", Strategy: smartrouting.StrategyCost},
+			{BINCountry: "usa", Strategy: smartrouting.StrategyCost},
+			{MinAmount: 500, MaxAmount: 100, Strategy: smartrouting.StrategyCost},
+			{Strategy: "random"},
+		} {
+			_, err := service.CreateRule(ctx, rule)
+			assert.ErrorIs(t, err, smartrouting.ErrInvalidRule)
+		}
+
+		_, err := service.GetRule(ctx, "rrl_missing")
+		assert.ErrorIs(t, err, smartrouting.ErrRuleNotFound)
+		assert.ErrorIs(t, service.DeleteRule(ctx, "rrl_missing"), smartrouting.ErrRuleNotFound)
+	})
+
+	t.Run("should order failover chains and record authorization outcomes", func(t *testing.T) {
+		service, _, stats := setup(smartrouting.StrategyCost)
+		router := routing.NewRouter(&MockRoutingStore{}, &routing.Config{DefaultProvider: "stripe"})
+		gateways := MockFailoverGateways{
+			"adyen":  &MockFailoverGateway{provider: "adyen", err: resilience.ErrCircuitOpen},
+			"stripe": &MockFailoverGateway{provider: "stripe"},
+			"paypal": &MockFailoverGateway{provider: "paypal"},
+		}
+		charger := services.NewFailoverCharger(gateways, router)
+		charger.UsePolicy(service)
+
+		request := services.CreateChargeRequest{Amount: 10000, Currency: "eur", Country: "nl"}
+		charge, attempts, err := charger.CreateCharge(ctx, tenancy.DefaultTenantID, "paypal", request)
+		require.NoError(t, err)
+		assert.Equal(t, "stripe", charge.Provider)
+		require.Len(t, attempts, 3)
+		assert.Equal(t, "adyen", attempts[0].Provider)
+		assert.Equal(t, services.FailoverSkipped, attempts[1].Outcome, "square has no credentials")
+		require.Len(t, stats.recorded, 1, "attempts the provider never processed are not recorded")
+		assert.Equal(t, "stripe", stats.recorded[0].Provider)
+		assert.True(t, stats.recorded[0].Authorized)
+
+		gateways["stripe"].err = resilience.ErrCircuitOpen
+		charge, attempts, err = charger.CreateCharge(ctx, tenancy.DefaultTenantID, "paypal", request)
+		require.NoError(t, err)
+		assert.Equal(t, "paypal", charge.Provider, "the default provider is the last resort")
+		assert.Len(t, attempts, 4)
+	})
+}
+
+// MockSmartRoutingStore keeps routing rules in memory
+type MockSmartRoutingStore struct {
+	rules map[string]*smartrouting.Rule
+}
+
+func (m *MockSmartRoutingStore) CreateSmartRoutingRule(ctx context.Context, rule *smartrouting.Rule) (*smartrouting.Rule, error) {
+	stored := *rule
+	stored.CreatedAt = time.Now().Add(time.Duration(len(m.rules)) * time.Millisecond)
+	m.rules[rule.ID] = &stored
+	return &stored, nil
+}
+
+func (m *MockSmartRoutingStore) GetSmartRoutingRule(ctx context.Context, id string) (*smartrouting.Rule, error) {
+	rule, ok := m.rules[id]
+	if !ok {
+		return nil, fmt.Errorf("failed to get smart routing rule: %w", sql.ErrNoRows)
+	}
+	return rule, nil
+}
+
+func (m *MockSmartRoutingStore) ListSmartRoutingRules(ctx context.Context) ([]*smartrouting.Rule, error) {
+	rules := make([]*smartrouting.Rule, 0, len(m.rules))
+	for _, rule := range m.rules {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].Priority != rules[j].Priority {
+			return rules[i].Priority < rules[j].Priority
+		}
+		return rules[i].CreatedAt.Before(rules[j].CreatedAt)
+	})
+	return rules, nil
+}
+
+func (m *MockSmartRoutingStore) UpdateSmartRoutingRule(ctx context.Context, rule *smartrouting.Rule) (*smartrouting.Rule, error) {
+	existing, ok := m.rules[rule.ID]
+	if !ok {
+		return nil, fmt.Errorf("failed to update smart routing rule: %w", sql.ErrNoRows)
+	}
+	stored := *rule
+	stored.CreatedAt = existing.CreatedAt
+	m.rules[rule.ID] = &stored
+	return &stored, nil
+}
+
+func (m *MockSmartRoutingStore) DeleteSmartRoutingRule(ctx context.Context, id string) (bool, error) {
+	_, ok := m.rules[id]
+	delete(m.rules, id)
+	return ok, nil
+}
+
+// MockRoutingStats serves fixed authorization rates and records attempts
+type MockRoutingStats struct {
+	rates    []*smartrouting.AuthRate
+	recorded []*smartrouting.Attempt
+}
+
+func (m *MockRoutingStats) RecordChargeAttempt(ctx context.Context, attempt *smartrouting.Attempt) error {
+	m.recorded = append(m.recorded, attempt)
+	return nil
+}
+
+func (m *MockRoutingStats) AuthorizationRates(ctx context.Context, since time.Time) ([]*smartrouting.AuthRate, error) {
+	return m.rates, nil
+}
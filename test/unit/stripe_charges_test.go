@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"apis/payments/services/money"
 	"apis/payments/services/stripe"
 
 	"github.com/stretchr/testify/assert"
@@ -74,6 +75,74 @@ func TestStripeCharges(t *testing.T) {
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "validation failed")
 	})
+
+	t.Run("should require a payment method or legacy source", func(t *testing.T) {
+		request := &stripe.ChargeRequest{
+			Amount:     2000,
+			Currency:   "usd",
+			CustomerID: "cus_test123",
+		}
+
+		chargeService := stripe.NewChargeService()
+		_, err := chargeService.CreateCharge(context.Background(), request)
+		assert.ErrorIs(t, err, stripe.ErrPaymentMethodRequired)
+		assert.ErrorIs(t, chargeService.ValidateChargeRequest(request), stripe.ErrPaymentMethodRequired)
+
+		request.PaymentMethod = "pm_card_visa"
+		assert.NoError(t, chargeService.ValidateChargeRequest(request))
+	})
+
+	t.Run("should only accept application fees on destination charges", func(t *testing.T) {
+		chargeService := stripe.NewChargeService()
+		request := &stripe.ChargeRequest{
+			Amount:               2000,
+			Currency:             "usd",
+			CustomerID:           "cus_test123",
+			PaymentMethod:        "pm_card_visa",
+			ApplicationFeeAmount: 200,
+		}
+
+		assert.ErrorIs(t, chargeService.ValidateChargeRequest(request), stripe.ErrInvalidApplicationFee)
+		_, err := chargeService.CreateCharge(context.Background(), request)
+		assert.ErrorIs(t, err, stripe.ErrInvalidApplicationFee)
+
+		request.Destination = "acct_123"
+		assert.NoError(t, chargeService.ValidateChargeRequest(request))
+
+		// The connected account must receive something
+		request.ApplicationFeeAmount = 2000
+		assert.ErrorIs(t, chargeService.ValidateChargeRequest(request), stripe.ErrInvalidApplicationFee)
+	})
+
+	t.Run("should validate amounts in the currency's minor units", func(t *testing.T) {
+		chargeService := stripe.NewChargeService()
+		request := &stripe.ChargeRequest{
+			Amount:        100, // ¥100, not ¥1.00
+			Currency:      "jpy",
+			CustomerID:    "cus_test123",
+			PaymentMethod: "pm_card_visa",
+		}
+		assert.NoError(t, chargeService.ValidateChargeRequest(request))
+
+		request.Currency = "usd" // $1.00
+		assert.NoError(t, chargeService.ValidateChargeRequest(request))
+
+		request.Amount = 30
+		assert.ErrorIs(t, chargeService.ValidateChargeRequest(request), money.ErrBelowMinimum)
+
+		request.Currency = "dollars"
+		assert.ErrorIs(t, chargeService.ValidateChargeRequest(request), money.ErrInvalidCurrency)
+	})
+
+	t.Run("should pass stored legacy source IDs through to payment intents", func(t *testing.T) {
+		chargeService := stripe.NewChargeService()
+
+		for _, source := range []string{"card_123", "src_123", "pm_123"} {
+			paymentMethod, err := chargeService.PaymentMethodForSource(context.Background(), source)
+			require.NoError(t, err)
+			assert.Equal(t, source, paymentMethod)
+		}
+	})
 }
 
 // MockChargeService is a mock implementation for testing
@@ -89,3 +158,19 @@ func (m *MockChargeService) CreateCharge(ctx context.Context, request *stripe.Ch
 	}
 	return m.mockCharge, nil
 }
+
+// TestPreferTokenized tests ordering payment methods for tokenized retries
+func TestPreferTokenized(t *testing.T) {
+	t.Run("should move tokenized payment methods first and keep relative order", func(t *testing.T) {
+		methods := []*stripe.PaymentMethod{{ID: "pm_pan_1"}, {ID: "pm_token_1"}, {ID: "pm_pan_2"}, {ID: "pm_token_2"}}
+
+		ordered := stripe.PreferTokenized(methods, []string{"pm_token_2", "pm_token_1"})
+
+		ids := make([]string, len(ordered))
+		for i, method := range ordered {
+			ids[i] = method.ID
+		}
+		assert.Equal(t, []string{"pm_token_1", "pm_token_2", "pm_pan_1", "pm_pan_2"}, ids)
+		assert.Equal(t, "pm_pan_1", methods[0].ID, "input should not be reordered")
+	})
+}
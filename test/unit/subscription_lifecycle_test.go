@@ -0,0 +1,174 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"apis/payments/services/events"
+	"apis/payments/services/stripe"
+	"apis/payments/services/subscriptions"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSubscriptionLifecycle tests subscription changes through the API and
+// the events published for them
+func TestSubscriptionLifecycle(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func() (*subscriptions.Service, *MockSubscriptionProvider, *MockEventPublisher) {
+		provider := NewMockSubscriptionProvider()
+		publisher := &MockEventPublisher{}
+		source, err := events.NewSource("/payments")
+		require.NoError(t, err)
+		return subscriptions.NewService(provider, events.NewEmitter(source, publisher)), provider, publisher
+	}
+
+	t.Run("should publish created, updated and canceled events", func(t *testing.T) {
+		service, _, publisher := setup()
+
+		subscription, err := service.Create(ctx, &stripe.SubscriptionRequest{CustomerID: "cus_1", PriceID: "price_basic"})
+		require.NoError(t, err)
+
+		_, err = service.Update(ctx, subscription.ID, &subscriptions.UpdateRequest{PriceID: "price_pro"})
+		require.NoError(t, err)
+
+		canceled, err := service.Cancel(ctx, subscription.ID, &subscriptions.CancelRequest{AtPeriodEnd: true})
+		require.NoError(t, err)
+		assert.True(t, canceled.CancelAtPeriodEnd)
+
+		require.Len(t, publisher.events, 3)
+		assert.Equal(t, subscriptions.EventCreated, publisher.events[0].Type)
+		assert.Equal(t, subscriptions.EventUpdated, publisher.events[1].Type)
+		assert.Equal(t, subscriptions.EventCanceled, publisher.events[2].Type)
+		for _, event := range publisher.events {
+			assert.Equal(t, subscription.ID, event.Subject)
+		}
+	})
+
+	t.Run("should reactivate a subscription set to cancel at period end", func(t *testing.T) {
+		service, _, publisher := setup()
+
+		subscription, err := service.Create(ctx, &stripe.SubscriptionRequest{CustomerID: "cus_1", PriceID: "price_basic"})
+		require.NoError(t, err)
+		_, err = service.Cancel(ctx, subscription.ID, &subscriptions.CancelRequest{AtPeriodEnd: true})
+		require.NoError(t, err)
+
+		reactivated, err := service.Reactivate(ctx, subscription.ID)
+		require.NoError(t, err)
+		assert.False(t, reactivated.CancelAtPeriodEnd)
+		assert.Equal(t, subscriptions.EventUpdated, publisher.events[len(publisher.events)-1].Type)
+
+		_, err = service.Cancel(ctx, subscription.ID, &subscriptions.CancelRequest{})
+		require.NoError(t, err)
+		_, err = service.Reactivate(ctx, subscription.ID)
+		assert.ErrorIs(t, err, stripe.ErrNotReactivatable)
+	})
+
+	t.Run("should reject empty updates without calling the provider", func(t *testing.T) {
+		service, provider, publisher := setup()
+
+		_, err := service.Update(ctx, "sub_1", &subscriptions.UpdateRequest{})
+		assert.ErrorIs(t, err, subscriptions.ErrEmptyUpdate)
+		assert.Zero(t, provider.calls)
+		assert.Empty(t, publisher.events)
+	})
+
+	t.Run("should not publish failed changes", func(t *testing.T) {
+		service, provider, publisher := setup()
+		provider.err = errors.New("card declined")
+
+		_, err := service.Create(ctx, &stripe.SubscriptionRequest{CustomerID: "cus_1", PriceID: "price_basic"})
+		assert.Error(t, err)
+		assert.Empty(t, publisher.events)
+	})
+
+	t.Run("should only list by known statuses", func(t *testing.T) {
+		assert.True(t, subscriptions.ValidStatus(""))
+		assert.True(t, subscriptions.ValidStatus("past_due"))
+		assert.True(t, subscriptions.ValidStatus("all"))
+		assert.False(t, subscriptions.ValidStatus("expired"))
+	})
+}
+
+// MockSubscriptionProvider keeps subscriptions in memory
+type MockSubscriptionProvider struct {
+	subscriptions map[string]*stripe.Subscription
+	plans         map[string]*stripe.SubscriptionPlan
+	changes       []*stripe.SubscriptionChange
+	calls         int
+	err           error
+}
+
+// NewMockSubscriptionProvider creates a provider without subscriptions
+func NewMockSubscriptionProvider() *MockSubscriptionProvider {
+	return &MockSubscriptionProvider{
+		subscriptions: make(map[string]*stripe.Subscription),
+		plans:         make(map[string]*stripe.SubscriptionPlan),
+	}
+}
+
+func (m *MockSubscriptionProvider) CreateSubscription(ctx context.Context, request *stripe.SubscriptionRequest) (*stripe.Subscription, error) {
+	m.calls++
+	if m.err != nil {
+		return nil, m.err
+	}
+	subscription := &stripe.Subscription{ID: "sub_1", CustomerID: request.CustomerID, PriceID: request.PriceID, Status: "active"}
+	m.subscriptions[subscription.ID] = subscription
+	return subscription, nil
+}
+
+func (m *MockSubscriptionProvider) GetSubscription(ctx context.Context, subscriptionID string) (*stripe.Subscription, error) {
+	subscription, ok := m.subscriptions[subscriptionID]
+	if !ok {
+		return nil, errors.New("subscription not found")
+	}
+	return subscription, nil
+}
+
+func (m *MockSubscriptionProvider) GetPlan(ctx context.Context, planID string) (*stripe.SubscriptionPlan, error) {
+	plan, ok := m.plans[planID]
+	if !ok {
+		return nil, errors.New("plan not found")
+	}
+	return plan, nil
+}
+
+func (m *MockSubscriptionProvider) UpdateSubscription(ctx context.Context, subscriptionID string, change *stripe.SubscriptionChange, metadata map[string]string) (*stripe.Subscription, error) {
+	m.calls++
+	m.changes = append(m.changes, change)
+	subscription := m.subscriptions[subscriptionID]
+	if change.PriceID != "" {
+		subscription.PriceID = change.PriceID
+	}
+	if change.Quantity > 0 {
+		subscription.Quantity = change.Quantity
+	}
+	if metadata != nil {
+		subscription.Metadata = metadata
+	}
+	return subscription, nil
+}
+
+func (m *MockSubscriptionProvider) CancelSubscription(ctx context.Context, subscriptionID string, atPeriodEnd bool) (*stripe.Subscription, error) {
+	m.calls++
+	subscription := m.subscriptions[subscriptionID]
+	if atPeriodEnd {
+		subscription.CancelAtPeriodEnd = true
+	} else {
+		subscription.Status = "canceled"
+	}
+	return subscription, nil
+}
+
+func (m *MockSubscriptionProvider) ReactivateSubscription(ctx context.Context, subscriptionID string) (*stripe.Subscription, error) {
+	m.calls++
+	subscription := m.subscriptions[subscriptionID]
+	if !subscription.CancelAtPeriodEnd || subscription.Status == "canceled" {
+		return nil, stripe.ErrNotReactivatable
+	}
+	subscription.CancelAtPeriodEnd = false
+	return subscription, nil
+}
@@ -0,0 +1,83 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"apis/payments/services/stripe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// TestScheduledPlanChanges tests notifications for plan changes applied at period end
+func TestScheduledPlanChanges(t *testing.T) {
+	newEvent := func(raw string, previous map[string]interface{}) stripego.Event {
+		return stripego.Event{
+			ID:   "evt_1",
+			Type: stripego.EventTypeCustomerSubscriptionUpdated,
+			Data: &stripego.EventData{Raw: []byte(raw), PreviousAttributes: previous},
+		}
+	}
+
+	setup := func() (*stripe.WebhookService, *[]*stripe.PlanChange) {
+		var applied []*stripe.PlanChange
+		subscriptions := stripe.NewSubscriptionService()
+		subscriptions.OnPlanChangeApplied(func(ctx context.Context, change *stripe.PlanChange) error {
+			applied = append(applied, change)
+			return nil
+		})
+
+		webhooks := stripe.NewWebhookService("whsec_test")
+		subscriptions.RegisterWebhookHandlers(webhooks)
+		return webhooks, &applied
+	}
+
+	t.Run("should notify listeners when a scheduled change applies", func(t *testing.T) {
+		webhooks, applied := setup()
+
+		event := newEvent(`{
+			"id": "sub_1",
+			"customer": "cus_1",
+			"current_period_start": 1700000000,
+			"metadata": {"plan_change_from": "price_pro", "plan_change_to": "price_basic"},
+			"items": {"data": [{"price": {"id": "price_basic"}}]}
+		}`, map[string]interface{}{"items": map[string]interface{}{}})
+
+		require.NoError(t, webhooks.Dispatch(context.Background(), event))
+		require.Len(t, *applied, 1)
+
+		change := (*applied)[0]
+		assert.Equal(t, "sub_1", change.SubscriptionID)
+		assert.Equal(t, "cus_1", change.CustomerID)
+		assert.Equal(t, "price_pro", change.FromPriceID)
+		assert.Equal(t, "price_basic", change.ToPriceID)
+		assert.Equal(t, int64(1700000000), change.EffectiveAt)
+	})
+
+	t.Run("should ignore updates that do not change items", func(t *testing.T) {
+		webhooks, applied := setup()
+
+		event := newEvent(`{
+			"id": "sub_1",
+			"metadata": {"plan_change_from": "price_pro", "plan_change_to": "price_basic"},
+			"items": {"data": [{"price": {"id": "price_basic"}}]}
+		}`, map[string]interface{}{"status": "past_due"})
+
+		require.NoError(t, webhooks.Dispatch(context.Background(), event))
+		assert.Empty(t, *applied)
+	})
+
+	t.Run("should ignore item changes that were not scheduled", func(t *testing.T) {
+		webhooks, applied := setup()
+
+		event := newEvent(`{
+			"id": "sub_1",
+			"items": {"data": [{"price": {"id": "price_enterprise"}}]}
+		}`, map[string]interface{}{"items": map[string]interface{}{}})
+
+		require.NoError(t, webhooks.Dispatch(context.Background(), event))
+		assert.Empty(t, *applied)
+	})
+}
@@ -0,0 +1,226 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"apis/payments/services"
+	"apis/payments/services/tenancy"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTenancy tests tenant resolution, tenant configurations and the
+// per-tenant provider credentials used by gateways
+func TestTenancy(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(requireRegistration bool) (*tenancy.Service, *MockTenantConfigStore) {
+		store := NewMockTenantConfigStore()
+		return tenancy.NewService(store, &tenancy.Config{RequireRegistration: requireRegistration, CacheTTL: time.Minute}), store
+	}
+
+	t.Run("should carry the tenant in the context", func(t *testing.T) {
+		_, ok := tenancy.FromContext(ctx)
+		assert.False(t, ok)
+		assert.Equal(t, tenancy.DefaultTenantID, tenancy.ID(ctx))
+
+		tenantCtx := tenancy.WithTenant(ctx, "tenant_1")
+		tenantID, ok := tenancy.FromContext(tenantCtx)
+		assert.True(t, ok)
+		assert.Equal(t, "tenant_1", tenantID)
+		assert.Equal(t, "tenant_1", tenancy.ID(tenantCtx))
+	})
+
+	t.Run("should resolve unregistered tenants unless registration is required", func(t *testing.T) {
+		service, _ := setup(false)
+		tenant, err := service.Resolve(ctx, "tenant_1")
+		require.NoError(t, err)
+		assert.Equal(t, "tenant_1", tenant.ID)
+		assert.Equal(t, "stripe", tenant.DefaultProvider)
+
+		service, _ = setup(true)
+		_, err = service.Resolve(ctx, "tenant_1")
+		assert.ErrorIs(t, err, tenancy.ErrUnknownTenant)
+
+		tenant, err = service.Resolve(ctx, tenancy.DefaultTenantID)
+		require.NoError(t, err)
+		assert.Equal(t, tenancy.DefaultTenantID, tenant.ID)
+	})
+
+	t.Run("should reject suspended tenants", func(t *testing.T) {
+		service, _ := setup(true)
+		_, err := service.Save(ctx, &tenancy.Tenant{ID: "tenant_1", Status: tenancy.StatusSuspended})
+		require.NoError(t, err)
+
+		_, err = service.Resolve(ctx, "tenant_1")
+		assert.ErrorIs(t, err, tenancy.ErrTenantSuspended)
+	})
+
+	t.Run("should cache configurations until they are saved", func(t *testing.T) {
+		service, store := setup(true)
+		_, err := service.Save(ctx, &tenancy.Tenant{ID: "tenant_1", Name: "Acme"})
+		require.NoError(t, err)
+
+		tenant, err := service.Resolve(ctx, "tenant_1")
+		require.NoError(t, err)
+		assert.Equal(t, "Acme", tenant.Name)
+		assert.Equal(t, tenancy.StatusActive, tenant.Status)
+
+		// Changed behind the service's back, the cached configuration is kept
+		store.tenants["tenant_1"].Status = tenancy.StatusSuspended
+		_, err = service.Resolve(ctx, "tenant_1")
+		assert.NoError(t, err)
+		assert.Equal(t, 1, store.gets)
+
+		_, err = service.Save(ctx, &tenancy.Tenant{ID: "tenant_1", Status: tenancy.StatusSuspended})
+		require.NoError(t, err)
+		_, err = service.Resolve(ctx, "tenant_1")
+		assert.ErrorIs(t, err, tenancy.ErrTenantSuspended)
+	})
+
+	t.Run("should validate configurations", func(t *testing.T) {
+		service, _ := setup(false)
+
+		_, err := service.Save(ctx, &tenancy.Tenant{})
+		assert.ErrorIs(t, err, tenancy.ErrInvalidTenant)
+		_, err = service.Save(ctx, &tenancy.Tenant{ID: "tenant_1", Status: "closed"})
+		assert.ErrorIs(t, err, tenancy.ErrInvalidTenant)
+		_, err = service.Save(ctx, &tenancy.Tenant{ID: "tenant_1", DefaultProvider: "acme"})
+		assert.ErrorIs(t, err, tenancy.ErrInvalidTenant)
+
+		_, err = service.Get(ctx, "tenant_2")
+		assert.ErrorIs(t, err, tenancy.ErrUnknownTenant)
+	})
+
+	t.Run("should use the platform key for the default tenant", func(t *testing.T) {
+		t.Setenv("STRIPE_SECRET_KEY", "sk_test_platform")
+		credentials := NewMockCredentialSource()
+		gateways := services.NewTenantGateways(credentials, time.Minute)
+
+		fields, err := gateways.Credentials(ctx, tenancy.DefaultTenantID, "stripe")
+		require.NoError(t, err)
+		assert.Equal(t, "sk_test_platform", fields["api_key"])
+
+		_, ok, err := gateways.SecretKey(ctx)
+		require.NoError(t, err)
+		assert.False(t, ok)
+		_, ok, err = gateways.SecretKey(tenancy.WithTenant(ctx, tenancy.DefaultTenantID))
+		require.NoError(t, err)
+		assert.False(t, ok)
+		assert.Equal(t, 0, credentials.resolves)
+	})
+
+	t.Run("should use a tenant's own key and never fall back to the platform key", func(t *testing.T) {
+		t.Setenv("STRIPE_SECRET_KEY", "sk_test_platform")
+		credentials := NewMockCredentialSource()
+		credentials.fields["tenant_1/stripe"] = map[string]string{"api_key": "sk_test_tenant_1"}
+		gateways := services.NewTenantGateways(credentials, time.Minute)
+
+		key, ok, err := gateways.SecretKey(tenancy.WithTenant(ctx, "tenant_1"))
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, "sk_test_tenant_1", key)
+
+		_, _, err = gateways.SecretKey(tenancy.WithTenant(ctx, "tenant_2"))
+		assert.ErrorIs(t, err, tenancy.ErrNoCredentials)
+	})
+
+	t.Run("should reload credentials once invalidated", func(t *testing.T) {
+		credentials := NewMockCredentialSource()
+		credentials.fields["tenant_1/stripe"] = map[string]string{"api_key": "sk_test_old"}
+		gateways := services.NewTenantGateways(credentials, time.Minute)
+		tenantCtx := tenancy.WithTenant(ctx, "tenant_1")
+
+		_, _, err := gateways.SecretKey(tenantCtx)
+		require.NoError(t, err)
+
+		credentials.fields["tenant_1/stripe"] = map[string]string{"api_key": "sk_test_new"}
+		key, _, err := gateways.SecretKey(tenantCtx)
+		require.NoError(t, err)
+		assert.Equal(t, "sk_test_old", key)
+		assert.Equal(t, 1, credentials.resolves)
+
+		gateways.Invalidate("tenant_1", "stripe")
+		key, _, err = gateways.SecretKey(tenantCtx)
+		require.NoError(t, err)
+		assert.Equal(t, "sk_test_new", key)
+		assert.Equal(t, 2, credentials.resolves)
+	})
+
+	t.Run("should build one gateway per tenant and provider", func(t *testing.T) {
+		credentials := NewMockCredentialSource()
+		credentials.fields["tenant_1/stripe"] = map[string]string{"api_key": "sk_test_tenant_1"}
+		gateways := services.NewTenantGateways(credentials, time.Minute)
+
+		gateway, err := gateways.Gateway(ctx, "tenant_1", "stripe")
+		require.NoError(t, err)
+		again, err := gateways.Gateway(ctx, "tenant_1", "stripe")
+		require.NoError(t, err)
+		assert.Same(t, gateway, again)
+
+		_, err = gateways.Gateway(ctx, "tenant_2", "stripe")
+		assert.ErrorIs(t, err, tenancy.ErrNoCredentials)
+	})
+}
+
+// MockTenantConfigStore is an in-memory tenancy.Store
+type MockTenantConfigStore struct {
+	tenants map[string]*tenancy.Tenant
+	gets    int
+}
+
+// NewMockTenantConfigStore creates an empty MockTenantConfigStore
+func NewMockTenantConfigStore() *MockTenantConfigStore {
+	return &MockTenantConfigStore{tenants: make(map[string]*tenancy.Tenant)}
+}
+
+func (m *MockTenantConfigStore) GetTenantConfig(ctx context.Context, tenantID string) (*tenancy.Tenant, error) {
+	m.gets++
+	tenant, ok := m.tenants[tenantID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *tenant
+	return &copied, nil
+}
+
+func (m *MockTenantConfigStore) ListTenantConfigs(ctx context.Context) ([]*tenancy.Tenant, error) {
+	tenants := make([]*tenancy.Tenant, 0, len(m.tenants))
+	for _, tenant := range m.tenants {
+		tenants = append(tenants, tenant)
+	}
+	return tenants, nil
+}
+
+func (m *MockTenantConfigStore) UpsertTenantConfig(ctx context.Context, tenant *tenancy.Tenant) (*tenancy.Tenant, error) {
+	stored := *tenant
+	stored.UpdatedAt = time.Now()
+	m.tenants[tenant.ID] = &stored
+	saved := stored
+	return &saved, nil
+}
+
+// MockCredentialSource is an in-memory services.CredentialSource keyed by
+// tenant and provider
+type MockCredentialSource struct {
+	fields   map[string]map[string]string
+	resolves int
+}
+
+// NewMockCredentialSource creates an empty MockCredentialSource
+func NewMockCredentialSource() *MockCredentialSource {
+	return &MockCredentialSource{fields: make(map[string]map[string]string)}
+}
+
+func (m *MockCredentialSource) Resolve(ctx context.Context, tenantID, provider string) (map[string]string, error) {
+	m.resolves++
+	fields, ok := m.fields[tenantID+"/"+provider]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return fields, nil
+}
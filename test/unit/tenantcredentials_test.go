@@ -0,0 +1,212 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"apis/payments/services/tenantcredentials"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTenantCredentials tests storing tenant provider credentials encrypted,
+// verified and audited
+func TestTenantCredentials(t *testing.T) {
+	actor := tenantcredentials.Actor{APIKeyID: "key_1234abcd", OperatorID: "ops@example.com"}
+
+	setup := func() (*tenantcredentials.Service, *MockCredentialStore, *MockCredentialVerifier) {
+		cipher, err := tenantcredentials.NewCipher(bytes.Repeat([]byte{7}, 32))
+		require.NoError(t, err)
+		store := NewMockCredentialStore()
+		verifier := &MockCredentialVerifier{}
+		return tenantcredentials.NewService(store, cipher, verifier), store, verifier
+	}
+
+	t.Run("should mask secrets while keeping a recognizable prefix", func(t *testing.T) {
+		assert.Equal(t, "sk_live_****cdef", tenantcredentials.Mask("sk_live_0123456789abcdef"))
+		assert.Equal(t, "****wxyz", tenantcredentials.Mask("EAAAl0123456789wxyz"))
+		assert.Equal(t, "******", tenantcredentials.Mask("secret"))
+	})
+
+	t.Run("should store credentials encrypted and return them masked", func(t *testing.T) {
+		service, store, verifier := setup()
+
+		credential, err := service.Save(context.Background(), actor, "acme", "stripe", map[string]string{
+			"api_key":         "sk_test_0123456789abcdef",
+			"publishable_key": "pk_test_0123456789abcdef",
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, credential.Version)
+		assert.Equal(t, "sk_test_****cdef", credential.Fields["api_key"])
+		assert.Equal(t, "pk_test_0123456789abcdef", credential.Fields["publishable_key"])
+		assert.NotContains(t, string(store.records["acme/stripe"].Ciphertext), "sk_test_0123456789abcdef")
+		assert.Equal(t, 1, verifier.calls)
+
+		fields, err := service.Resolve(context.Background(), "acme", "stripe")
+		require.NoError(t, err)
+		assert.Equal(t, "sk_test_0123456789abcdef", fields["api_key"])
+
+		require.Len(t, store.audit, 1)
+		assert.Equal(t, tenantcredentials.ActionCreated, store.audit[0].Action)
+		assert.Equal(t, []string{"api_key", "publishable_key"}, store.audit[0].ChangedFields)
+		assert.Equal(t, "key_1234abcd", store.audit[0].APIKeyID)
+		assert.Equal(t, "ops@example.com", store.audit[0].OperatorID)
+	})
+
+	t.Run("should refuse credentials bound to another tenant", func(t *testing.T) {
+		service, store, _ := setup()
+
+		_, err := service.Save(context.Background(), actor, "acme", "stripe", map[string]string{"api_key": "sk_test_0123456789abcdef"})
+		require.NoError(t, err)
+
+		stolen := *store.records["acme/stripe"]
+		stolen.TenantID = "globex"
+		store.records["globex/stripe"] = &stolen
+
+		_, err = service.Resolve(context.Background(), "globex", "stripe")
+		assert.Error(t, err)
+	})
+
+	t.Run("should reject invalid and unverified credentials without storing them", func(t *testing.T) {
+		service, store, verifier := setup()
+
+		_, err := service.Save(context.Background(), actor, "acme", "paddle", map[string]string{"api_key": "pdl_0123456789"})
+		assert.ErrorIs(t, err, tenantcredentials.ErrInvalidCredentials)
+
+		_, err = service.Save(context.Background(), actor, "acme", "stripe", map[string]string{"api_key": "sk_test_1", "password": "hunter2"})
+		assert.ErrorIs(t, err, tenantcredentials.ErrInvalidCredentials)
+
+		_, err = service.Save(context.Background(), actor, "acme", "adyen", map[string]string{"api_key": "AQE123"})
+		assert.ErrorIs(t, err, tenantcredentials.ErrInvalidCredentials)
+
+		verifier.err = errors.New("invalid api key")
+		_, err = service.Save(context.Background(), actor, "acme", "stripe", map[string]string{"api_key": "sk_test_0123456789abcdef"})
+		assert.ErrorIs(t, err, tenantcredentials.ErrVerificationFailed)
+
+		assert.Empty(t, store.records)
+		assert.Empty(t, store.audit)
+	})
+
+	t.Run("should rotate secrets keeping the other fields", func(t *testing.T) {
+		service, store, _ := setup()
+
+		_, err := service.Save(context.Background(), actor, "acme", "square", map[string]string{
+			"application_id": "sq0idp-app",
+			"access_token":   "EAAA_old_token_1234",
+			"environment":    "sandbox",
+		})
+		require.NoError(t, err)
+
+		_, err = service.Rotate(context.Background(), actor, "acme", "square", map[string]string{"environment": "production"})
+		assert.ErrorIs(t, err, tenantcredentials.ErrInvalidCredentials)
+
+		_, err = service.Rotate(context.Background(), actor, "acme", "square", map[string]string{"access_token": "EAAA_old_token_1234"})
+		assert.ErrorIs(t, err, tenantcredentials.ErrInvalidCredentials)
+
+		credential, err := service.Rotate(context.Background(), actor, "acme", "square", map[string]string{"access_token": "EAAA_new_token_5678"})
+		require.NoError(t, err)
+		assert.Equal(t, 2, credential.Version)
+
+		fields, err := service.Resolve(context.Background(), "acme", "square")
+		require.NoError(t, err)
+		assert.Equal(t, "EAAA_new_token_5678", fields["access_token"])
+		assert.Equal(t, "sq0idp-app", fields["application_id"])
+
+		require.Len(t, store.audit, 2)
+		assert.Equal(t, tenantcredentials.ActionRotated, store.audit[1].Action)
+		assert.Equal(t, []string{"access_token"}, store.audit[1].ChangedFields)
+	})
+
+	t.Run("should audit deletions", func(t *testing.T) {
+		service, store, _ := setup()
+
+		deleted, err := service.Delete(context.Background(), actor, "acme", "stripe")
+		require.NoError(t, err)
+		assert.False(t, deleted)
+
+		_, err = service.Save(context.Background(), actor, "acme", "stripe", map[string]string{"api_key": "sk_test_0123456789abcdef"})
+		require.NoError(t, err)
+
+		deleted, err = service.Delete(context.Background(), actor, "acme", "stripe")
+		require.NoError(t, err)
+		assert.True(t, deleted)
+		assert.Empty(t, store.records)
+		assert.Equal(t, tenantcredentials.ActionDeleted, store.audit[len(store.audit)-1].Action)
+	})
+
+	t.Run("should refuse to store credentials without an encryption key", func(t *testing.T) {
+		t.Setenv("CREDENTIALS_ENCRYPTION_KEY", "")
+		_, err := tenantcredentials.LoadCipher()
+		assert.ErrorIs(t, err, tenantcredentials.ErrEncryptionNotConfigured)
+
+		service := tenantcredentials.NewService(NewMockCredentialStore(), nil, &MockCredentialVerifier{})
+		_, err = service.Save(context.Background(), actor, "acme", "stripe", map[string]string{"api_key": "sk_test_0123456789abcdef"})
+		assert.ErrorIs(t, err, tenantcredentials.ErrEncryptionNotConfigured)
+	})
+}
+
+// MockCredentialStore keeps credentials and audit entries in memory
+type MockCredentialStore struct {
+	records map[string]*tenantcredentials.Record
+	audit   []*tenantcredentials.AuditEntry
+}
+
+// NewMockCredentialStore creates an empty credential store
+func NewMockCredentialStore() *MockCredentialStore {
+	return &MockCredentialStore{records: map[string]*tenantcredentials.Record{}}
+}
+
+func (m *MockCredentialStore) GetProviderCredential(ctx context.Context, tenantID, provider string) (*tenantcredentials.Record, error) {
+	record, ok := m.records[tenantID+"/"+provider]
+	if !ok {
+		return nil, fmt.Errorf("failed to get provider credential: %w", sql.ErrNoRows)
+	}
+	return record, nil
+}
+
+func (m *MockCredentialStore) ListProviderCredentials(ctx context.Context, tenantID string) ([]*tenantcredentials.Record, error) {
+	var records []*tenantcredentials.Record
+	for _, record := range m.records {
+		if record.TenantID == tenantID {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+func (m *MockCredentialStore) UpsertProviderCredential(ctx context.Context, record *tenantcredentials.Record) (*tenantcredentials.Record, error) {
+	m.records[record.TenantID+"/"+record.Provider] = record
+	return record, nil
+}
+
+func (m *MockCredentialStore) DeleteProviderCredential(ctx context.Context, tenantID, provider string) (bool, error) {
+	_, ok := m.records[tenantID+"/"+provider]
+	delete(m.records, tenantID+"/"+provider)
+	return ok, nil
+}
+
+func (m *MockCredentialStore) CreateProviderCredentialAudit(ctx context.Context, entry *tenantcredentials.AuditEntry) error {
+	m.audit = append(m.audit, entry)
+	return nil
+}
+
+func (m *MockCredentialStore) ListProviderCredentialAudit(ctx context.Context, tenantID, provider string, limit int) ([]*tenantcredentials.AuditEntry, error) {
+	return m.audit, nil
+}
+
+// MockCredentialVerifier accepts credentials unless err is set
+type MockCredentialVerifier struct {
+	calls int
+	err   error
+}
+
+func (m *MockCredentialVerifier) Verify(ctx context.Context, provider string, fields map[string]string) error {
+	m.calls++
+	return m.err
+}
@@ -0,0 +1,259 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"apis/payments/services/stripe"
+	"apis/payments/services/usage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUsage tests recording metered usage, pushing it to the provider and
+// totalling consumption
+func TestUsage(t *testing.T) {
+	ctx := context.Background()
+	periodStart := time.Now().Add(-24 * time.Hour).Truncate(time.Second)
+
+	setup := func(aggregateUsage string) (*usage.Service, *MockUsageStore, *MockUsageProvider) {
+		store := NewMockUsageStore()
+		provider := &MockUsageProvider{item: &stripe.MeteredItem{
+			ID:                 "si_1",
+			SubscriptionID:     "sub_1",
+			PriceID:            "price_metered",
+			AggregateUsage:     aggregateUsage,
+			CurrentPeriodStart: periodStart.Unix(),
+			CurrentPeriodEnd:   periodStart.Add(30 * 24 * time.Hour).Unix(),
+		}}
+		service := usage.NewService(store, provider, &usage.Config{ReportInterval: time.Minute, MaxAttempts: 3, BatchSize: 10})
+		return service, store, provider
+	}
+	at := func(offset time.Duration) *time.Time {
+		timestamp := periodStart.Add(offset)
+		return &timestamp
+	}
+
+	t.Run("should push sum usage as increments and total it", func(t *testing.T) {
+		service, _, provider := setup("sum")
+
+		for i, quantity := range []int64{5, 3, 7} {
+			record, err := service.Report(ctx, "sub_1", &usage.ReportRequest{Quantity: quantity, Timestamp: at(time.Duration(i+1) * time.Hour)})
+			require.NoError(t, err)
+			assert.Equal(t, stripe.UsageActionIncrement, record.Action)
+			assert.NotNil(t, record.ReportedAt)
+			assert.Equal(t, "si_1", record.SubscriptionItemID)
+		}
+		require.Len(t, provider.reported, 3)
+		assert.Equal(t, "ur_", provider.reported[0].IdempotencyKey[:3], "records are pushed idempotently by their ID")
+
+		consumption, err := service.Consumption(ctx, "sub_1", usage.Window{})
+		require.NoError(t, err)
+		assert.Equal(t, usage.AggregationSum, consumption.Aggregation)
+		assert.Equal(t, int64(15), consumption.Quantity)
+		assert.Len(t, consumption.Records, 3)
+		assert.Zero(t, consumption.Unreported)
+	})
+
+	t.Run("should set max and last usage and total by their aggregation", func(t *testing.T) {
+		service, _, provider := setup("max")
+
+		for i, quantity := range []int64{4, 9, 2} {
+			_, err := service.Report(ctx, "sub_1", &usage.ReportRequest{Quantity: quantity, Timestamp: at(time.Duration(i+1) * time.Hour)})
+			require.NoError(t, err)
+		}
+		assert.Equal(t, stripe.UsageActionSet, provider.reported[0].Action)
+
+		consumption, err := service.Consumption(ctx, "sub_1", usage.Window{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(9), consumption.Quantity)
+
+		consumption, err = service.Consumption(ctx, "sub_1", usage.Window{Aggregation: usage.AggregationLast})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), consumption.Quantity)
+
+		consumption, err = service.Consumption(ctx, "sub_1", usage.Window{From: at(90 * time.Minute), To: at(150 * time.Minute)})
+		require.NoError(t, err)
+		assert.Equal(t, int64(9), consumption.Quantity, "only usage in the window counts")
+		assert.Len(t, consumption.Records, 1)
+
+		_, err = service.Consumption(ctx, "sub_1", usage.Window{Aggregation: "avg"})
+		assert.ErrorIs(t, err, usage.ErrInvalidUsage)
+	})
+
+	t.Run("should count a report once per idempotency key", func(t *testing.T) {
+		service, _, provider := setup("sum")
+
+		first, err := service.Report(ctx, "sub_1", &usage.ReportRequest{Quantity: 5, IdempotencyKey: "key_1"})
+		require.NoError(t, err)
+		again, err := service.Report(ctx, "sub_1", &usage.ReportRequest{Quantity: 5, IdempotencyKey: "key_1"})
+		require.NoError(t, err)
+
+		assert.Equal(t, first.ID, again.ID)
+		assert.Len(t, provider.reported, 1)
+	})
+
+	t.Run("should reject usage outside the current period", func(t *testing.T) {
+		service, _, provider := setup("sum")
+
+		future := time.Now().Add(time.Hour)
+		_, err := service.Report(ctx, "sub_1", &usage.ReportRequest{Quantity: 1, Timestamp: &future})
+		assert.ErrorIs(t, err, usage.ErrInvalidUsage)
+		_, err = service.Report(ctx, "sub_1", &usage.ReportRequest{Quantity: 1, Timestamp: at(-time.Hour)})
+		assert.ErrorIs(t, err, usage.ErrInvalidUsage)
+		_, err = service.Report(ctx, "sub_1", &usage.ReportRequest{Quantity: -1})
+		assert.ErrorIs(t, err, usage.ErrInvalidUsage)
+		assert.Empty(t, provider.reported)
+	})
+
+	t.Run("should refuse subscriptions without a metered price", func(t *testing.T) {
+		service, _, provider := setup("sum")
+		provider.item = nil
+
+		_, err := service.Report(ctx, "sub_1", &usage.ReportRequest{Quantity: 1})
+		assert.ErrorIs(t, err, stripe.ErrNotMetered)
+	})
+
+	t.Run("should keep usage that fails to push and retry it", func(t *testing.T) {
+		service, store, provider := setup("sum")
+		provider.err = errors.New("provider unavailable")
+
+		record, err := service.Report(ctx, "sub_1", &usage.ReportRequest{Quantity: 5})
+		require.NoError(t, err)
+		assert.Nil(t, record.ReportedAt)
+		assert.Equal(t, 1, record.Attempts)
+		assert.Equal(t, "provider unavailable", record.LastError)
+
+		consumption, err := service.Consumption(ctx, "sub_1", usage.Window{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), consumption.Quantity, "unpushed usage still counts locally")
+		assert.Equal(t, 1, consumption.Unreported)
+
+		provider.err = nil
+		pushed, err := service.RetryPending(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, pushed)
+		assert.NotNil(t, store.records[record.ID].ReportedAt)
+
+		pushed, err = service.RetryPending(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, pushed)
+	})
+
+	t.Run("should give up on records out of attempts", func(t *testing.T) {
+		service, _, provider := setup("sum")
+		provider.err = errors.New("timestamp outside period")
+
+		_, err := service.Report(ctx, "sub_1", &usage.ReportRequest{Quantity: 5})
+		require.NoError(t, err)
+		for i := 0; i < 3; i++ {
+			_, err = service.RetryPending(ctx)
+			require.NoError(t, err)
+		}
+		assert.Len(t, provider.reported, 3, "a record is pushed at most MaxAttempts times")
+	})
+}
+
+// MockUsageStore keeps usage records in memory
+type MockUsageStore struct {
+	records map[string]*usage.Record
+}
+
+// NewMockUsageStore creates an empty usage store
+func NewMockUsageStore() *MockUsageStore {
+	return &MockUsageStore{records: make(map[string]*usage.Record)}
+}
+
+func (m *MockUsageStore) InsertUsageRecord(ctx context.Context, record *usage.Record) (bool, error) {
+	if record.IdempotencyKey != "" {
+		if _, err := m.GetUsageRecordByKey(ctx, record.SubscriptionID, record.IdempotencyKey); err == nil {
+			return false, nil
+		}
+	}
+	stored := *record
+	stored.CreatedAt = time.Now()
+	m.records[record.ID] = &stored
+	return true, nil
+}
+
+func (m *MockUsageStore) GetUsageRecord(ctx context.Context, id string) (*usage.Record, error) {
+	record, ok := m.records[id]
+	if !ok {
+		return nil, fmt.Errorf("failed to get usage record: %w", sql.ErrNoRows)
+	}
+	stored := *record
+	return &stored, nil
+}
+
+func (m *MockUsageStore) GetUsageRecordByKey(ctx context.Context, subscriptionID, idempotencyKey string) (*usage.Record, error) {
+	for _, record := range m.records {
+		if record.SubscriptionID == subscriptionID && record.IdempotencyKey == idempotencyKey {
+			stored := *record
+			return &stored, nil
+		}
+	}
+	return nil, fmt.Errorf("failed to get usage record: %w", sql.ErrNoRows)
+}
+
+func (m *MockUsageStore) ListUsageRecords(ctx context.Context, subscriptionID string, from, to time.Time) ([]*usage.Record, error) {
+	var records []*usage.Record
+	for _, record := range m.records {
+		if record.SubscriptionID == subscriptionID && !record.RecordedAt.Before(from) && record.RecordedAt.Before(to) {
+			records = append(records, record)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].RecordedAt.Before(records[j].RecordedAt) })
+	return records, nil
+}
+
+func (m *MockUsageStore) ListUnreportedUsageRecords(ctx context.Context, maxAttempts, limit int) ([]*usage.Record, error) {
+	var records []*usage.Record
+	for _, record := range m.records {
+		if record.ReportedAt == nil && record.Attempts < maxAttempts && len(records) < limit {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+func (m *MockUsageStore) MarkUsageRecordReported(ctx context.Context, id, providerRecordID string, reportedAt time.Time) (*usage.Record, error) {
+	record := m.records[id]
+	record.ProviderRecordID, record.ReportedAt, record.LastError = providerRecordID, &reportedAt, ""
+	record.Attempts++
+	return m.GetUsageRecord(ctx, id)
+}
+
+func (m *MockUsageStore) RecordUsageReportFailure(ctx context.Context, id, message string) error {
+	record := m.records[id]
+	record.Attempts++
+	record.LastError = message
+	return nil
+}
+
+// MockUsageProvider serves one metered item and records pushed usage
+type MockUsageProvider struct {
+	item     *stripe.MeteredItem
+	reported []*stripe.UsageRecordRequest
+	err      error
+}
+
+func (m *MockUsageProvider) MeteredItem(ctx context.Context, subscriptionID string) (*stripe.MeteredItem, error) {
+	if m.item == nil {
+		return nil, stripe.ErrNotMetered
+	}
+	return m.item, nil
+}
+
+func (m *MockUsageProvider) ReportUsage(ctx context.Context, request *stripe.UsageRecordRequest) (*stripe.UsageRecord, error) {
+	m.reported = append(m.reported, request)
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &stripe.UsageRecord{ID: fmt.Sprintf("mbur_%d", len(m.reported)), SubscriptionItemID: request.SubscriptionItemID, Quantity: request.Quantity, Timestamp: request.Timestamp}, nil
+}
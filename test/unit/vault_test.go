@@ -0,0 +1,166 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"apis/payments/services/vault"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestVault tests issuing and resolving provider-agnostic payment method tokens
+func TestVault(t *testing.T) {
+	setup := func() (*vault.Service, *MockVaultStore) {
+		store := NewMockVaultStore()
+		return vault.NewService(store), store
+	}
+
+	t.Run("should issue a pmt_ token for a provider payment method", func(t *testing.T) {
+		service, _ := setup()
+
+		token, err := service.Issue(context.Background(), &vault.Token{Provider: "stripe", ProviderToken: "pm_1", CustomerID: "cus_1", Type: "card"})
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(token.ID, vault.TokenPrefix))
+		assert.Equal(t, "cus_1", token.CustomerID)
+	})
+
+	t.Run("should reuse the token for a provider payment method already vaulted", func(t *testing.T) {
+		service, store := setup()
+
+		first, err := service.Issue(context.Background(), &vault.Token{Provider: "stripe", ProviderToken: "pm_1"})
+		require.NoError(t, err)
+		second, err := service.Issue(context.Background(), &vault.Token{Provider: "stripe", ProviderToken: "pm_1"})
+		require.NoError(t, err)
+
+		assert.Equal(t, first.ID, second.ID)
+		assert.Len(t, store.tokens, 1)
+	})
+
+	t.Run("should require a provider and provider token", func(t *testing.T) {
+		service, _ := setup()
+
+		_, err := service.Issue(context.Background(), &vault.Token{ProviderToken: "pm_1"})
+		assert.Error(t, err)
+		_, err = service.Issue(context.Background(), &vault.Token{Provider: "stripe"})
+		assert.Error(t, err)
+	})
+
+	t.Run("should resolve a token to its provider token", func(t *testing.T) {
+		service, _ := setup()
+		token, err := service.Issue(context.Background(), &vault.Token{Provider: "stripe", ProviderToken: "pm_1"})
+		require.NoError(t, err)
+
+		provider, providerToken, err := service.Resolve(context.Background(), token.ID, "stripe")
+		require.NoError(t, err)
+		assert.Equal(t, "stripe", provider)
+		assert.Equal(t, "pm_1", providerToken)
+	})
+
+	t.Run("should pass provider tokens through unchanged", func(t *testing.T) {
+		service, _ := setup()
+
+		provider, providerToken, err := service.Resolve(context.Background(), "pm_legacy", "stripe")
+		require.NoError(t, err)
+		assert.Equal(t, "stripe", provider)
+		assert.Equal(t, "pm_legacy", providerToken)
+	})
+
+	t.Run("should report unknown tokens as not found", func(t *testing.T) {
+		service, _ := setup()
+
+		_, _, err := service.Resolve(context.Background(), "pmt_missing", "stripe")
+		assert.ErrorIs(t, err, vault.ErrTokenNotFound)
+	})
+
+	t.Run("should keep the token ID when remapping to another provider", func(t *testing.T) {
+		service, _ := setup()
+		token, err := service.Issue(context.Background(), &vault.Token{Provider: "stripe", ProviderToken: "pm_1"})
+		require.NoError(t, err)
+
+		remapped, err := service.Remap(context.Background(), token.ID, "braintree", "bt_1")
+		require.NoError(t, err)
+		assert.Equal(t, token.ID, remapped.ID)
+
+		provider, providerToken, err := service.Resolve(context.Background(), token.ID, "stripe")
+		require.NoError(t, err)
+		assert.Equal(t, "braintree", provider)
+		assert.Equal(t, "bt_1", providerToken)
+	})
+
+	t.Run("should not remap unknown tokens", func(t *testing.T) {
+		service, _ := setup()
+
+		_, err := service.Remap(context.Background(), "pmt_missing", "braintree", "bt_1")
+		assert.ErrorIs(t, err, vault.ErrTokenNotFound)
+	})
+}
+
+// MockVaultStore keeps vault tokens in memory
+type MockVaultStore struct {
+	tokens map[string]*vault.Token
+}
+
+// NewMockVaultStore creates an empty vault store
+func NewMockVaultStore() *MockVaultStore {
+	return &MockVaultStore{tokens: make(map[string]*vault.Token)}
+}
+
+func (m *MockVaultStore) CreateVaultToken(ctx context.Context, token *vault.Token) (*vault.Token, error) {
+	m.tokens[token.ID] = token
+	return token, nil
+}
+
+func (m *MockVaultStore) GetVaultToken(ctx context.Context, id string) (*vault.Token, error) {
+	token, ok := m.tokens[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return token, nil
+}
+
+func (m *MockVaultStore) GetVaultTokenByProviderToken(ctx context.Context, provider, providerToken string) (*vault.Token, error) {
+	for _, token := range m.tokens {
+		if token.Provider == provider && token.ProviderToken == providerToken {
+			return token, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *MockVaultStore) ListVaultTokensByCustomer(ctx context.Context, customerID string) ([]*vault.Token, error) {
+	var tokens []*vault.Token
+	for _, token := range m.tokens {
+		if token.CustomerID == customerID {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens, nil
+}
+
+func (m *MockVaultStore) RemapVaultToken(ctx context.Context, id, provider, providerToken string) (*vault.Token, error) {
+	token, ok := m.tokens[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	token.Provider = provider
+	token.ProviderToken = providerToken
+	return token, nil
+}
+
+func (m *MockVaultStore) UpdateVaultTokenFingerprint(ctx context.Context, provider, providerToken, fingerprint string) error {
+	for _, token := range m.tokens {
+		if token.Provider == provider && token.ProviderToken == providerToken {
+			token.Fingerprint = fingerprint
+		}
+	}
+	return nil
+}
+
+func (m *MockVaultStore) DeleteVaultToken(ctx context.Context, id string) error {
+	delete(m.tokens, id)
+	return nil
+}
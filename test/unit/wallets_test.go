@@ -0,0 +1,115 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"apis/payments/services/stripe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	stripego "github.com/stripe/stripe-go/v76"
+)
+
+// TestWallets tests Apple Pay and Google Pay tokens, domains and the wallet
+// reported on charges and payment methods
+func TestWallets(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should extract the Stripe card token from Google Pay payment data", func(t *testing.T) {
+		cardToken, err := stripe.GooglePayCardToken(json.RawMessage(`{
+			"apiVersion": 2,
+			"paymentMethodData": {
+				"type": "CARD",
+				"tokenizationData": {"type": "PAYMENT_GATEWAY", "token": "{\"id\": \"tok_1\", \"object\": \"token\"}"}
+			}
+		}`))
+		require.NoError(t, err)
+		assert.Equal(t, "tok_1", cardToken)
+
+		_, err = stripe.GooglePayCardToken(json.RawMessage(`{"paymentMethodData": {"tokenizationData": {"type": "DIRECT", "token": "{}"}}}`))
+		assert.ErrorIs(t, err, stripe.ErrInvalidWalletToken)
+
+		_, err = stripe.GooglePayCardToken(json.RawMessage(`{"paymentMethodData": {"tokenizationData": {"type": "PAYMENT_GATEWAY", "token": "{\"id\": \"pm_1\"}"}}}`))
+		assert.ErrorIs(t, err, stripe.ErrInvalidWalletToken)
+	})
+
+	t.Run("should pass the Apple Pay payment data on to Stripe", func(t *testing.T) {
+		params, err := stripe.ApplePayTokenParams(json.RawMessage(`{
+			"paymentData": {"version": "EC_v1", "data": "ZW5jcnlwdGVk", "signature": "c2ln"},
+			"paymentMethod": {"displayName": "Visa 0326", "network": "Visa", "type": "debit"},
+			"transactionIdentifier": "A1B2C3"
+		}`))
+		require.NoError(t, err)
+
+		extra := params.Extra.Values
+		assert.JSONEq(t, `{"version": "EC_v1", "data": "ZW5jcnlwdGVk", "signature": "c2ln"}`, extra.Get("pk_token"))
+		assert.Equal(t, "A1B2C3", extra.Get("pk_token_transaction_id"))
+		assert.Equal(t, "Visa 0326", extra.Get("pk_token_instrument_name"))
+		assert.Equal(t, "Visa", extra.Get("pk_token_payment_network"))
+
+		_, err = stripe.ApplePayTokenParams(json.RawMessage(`{"paymentMethod": {"network": "Visa"}}`))
+		assert.ErrorIs(t, err, stripe.ErrInvalidWalletToken)
+	})
+
+	t.Run("should reject tokens before calling the provider", func(t *testing.T) {
+		wallets := stripe.NewWalletService()
+
+		_, err := wallets.PaymentMethodForToken(ctx, &stripe.WalletToken{Wallet: "samsung_pay", PaymentToken: json.RawMessage(`{}`)})
+		assert.ErrorIs(t, err, stripe.ErrUnsupportedWallet)
+
+		_, err = wallets.PaymentMethodForToken(ctx, &stripe.WalletToken{Wallet: stripe.WalletGooglePay})
+		assert.ErrorContains(t, err, "validation failed")
+
+		_, err = wallets.PaymentMethodForToken(ctx, &stripe.WalletToken{Wallet: stripe.WalletApplePay, PaymentToken: json.RawMessage(`"not a token"`)})
+		assert.ErrorIs(t, err, stripe.ErrInvalidWalletToken)
+	})
+
+	t.Run("should report the wallet of charges and payment methods", func(t *testing.T) {
+		charge := stripe.ConvertCharge(&stripego.Charge{
+			ID:     "ch_1",
+			Status: stripego.ChargeStatusSucceeded,
+			PaymentMethodDetails: &stripego.ChargePaymentMethodDetails{
+				Type: stripego.ChargePaymentMethodDetailsTypeCard,
+				Card: &stripego.ChargePaymentMethodDetailsCard{
+					Network: "visa",
+					Wallet:  &stripego.ChargePaymentMethodDetailsCardWallet{Type: "apple_pay"},
+				},
+			},
+		})
+		assert.Equal(t, stripe.WalletApplePay, charge.Wallet)
+		assert.Equal(t, stripe.CredentialDPAN, charge.CredentialType)
+
+		paymentMethod := stripe.ConvertPaymentMethod(&stripego.PaymentMethod{
+			ID:   "pm_1",
+			Type: stripego.PaymentMethodTypeCard,
+			Card: &stripego.PaymentMethodCard{
+				Last4:  "4242",
+				Wallet: &stripego.PaymentMethodCardWallet{Type: stripego.PaymentMethodCardWalletTypeGooglePay},
+			},
+		})
+		assert.Equal(t, stripe.WalletGooglePay, paymentMethod.Wallet)
+
+		card := stripe.ConvertPaymentMethod(&stripego.PaymentMethod{ID: "pm_2", Type: stripego.PaymentMethodTypeCard, Card: &stripego.PaymentMethodCard{Last4: "4242"}})
+		assert.Empty(t, card.Wallet)
+	})
+
+	t.Run("should convert domain verification status", func(t *testing.T) {
+		domain := stripe.ConvertWalletDomain(&stripego.PaymentMethodDomain{
+			ID:         "pmd_1",
+			DomainName: "shop.example.com",
+			Enabled:    true,
+			ApplePay: &stripego.PaymentMethodDomainApplePay{
+				Status:        stripego.PaymentMethodDomainApplePayStatusInactive,
+				StatusDetails: &stripego.PaymentMethodDomainApplePayStatusDetails{ErrorMessage: "domain association file not found"},
+			},
+			GooglePay: &stripego.PaymentMethodDomainGooglePay{Status: stripego.PaymentMethodDomainGooglePayStatusActive},
+		})
+
+		assert.Equal(t, "inactive", domain.ApplePayStatus)
+		assert.Equal(t, "domain association file not found", domain.ApplePayError)
+		assert.Equal(t, "active", domain.GooglePayStatus)
+		assert.Empty(t, domain.GooglePayError)
+	})
+}
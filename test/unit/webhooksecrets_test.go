@@ -0,0 +1,327 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"apis/payments/services/stripe"
+	"apis/payments/services/tenantcredentials"
+	"apis/payments/services/webhooksecrets"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stripe/stripe-go/v76/webhook"
+)
+
+// TestWebhookSecretRotation tests rotating the webhook signing secret with
+// both secrets accepted until deliveries with the new one are confirmed
+func TestWebhookSecretRotation(t *testing.T) {
+	payload := []byte(`{"id": "evt_1", "object": "event", "type": "charge.succeeded", "data": {"object": {}}}`)
+
+	sign := func(secret string) string {
+		return webhook.GenerateTestSignedPayload(&webhook.UnsignedPayload{Payload: payload, Secret: secret}).Header
+	}
+
+	setup := func(config *webhooksecrets.Config) (*webhooksecrets.Service, *MockWebhookSecretStore, *MockWebhookEndpoints, *stripe.WebhookService) {
+		cipher, err := tenantcredentials.NewCipher(make([]byte, 32))
+		require.NoError(t, err)
+
+		store := NewMockWebhookSecretStore()
+		endpoints := NewMockWebhookEndpoints()
+		service := webhooksecrets.NewService(store, endpoints, cipher, config)
+		webhooks := stripe.NewWebhookService("")
+		webhooks.UseSecretSource(service)
+		return service, store, endpoints, webhooks
+	}
+
+	newConfig := func() *webhooksecrets.Config {
+		return &webhooksecrets.Config{
+			EndpointID:    "we_old",
+			Secret:        "whsec_old",
+			GracePeriod:   time.Hour,
+			MinDeliveries: 2,
+			CheckInterval: time.Minute,
+		}
+	}
+
+	t.Run("should accept both secrets while a rotation is pending", func(t *testing.T) {
+		service, store, endpoints, webhooks := setup(newConfig())
+
+		_, _, err := webhooks.VerifyEvent(payload, sign("whsec_new_1"))
+		assert.Error(t, err)
+
+		rotation, err := service.Rotate(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, webhooksecrets.StatusPending, rotation.Status)
+		assert.Equal(t, "we_old", rotation.OldEndpointID)
+		assert.Equal(t, "we_new_1", rotation.NewEndpointID)
+		assert.NotContains(t, string(store.rotations[rotation.ID].Ciphertext), "whsec_new_1")
+		assert.Equal(t, []string{"we_old"}, endpoints.cloned)
+
+		event, secret, err := webhooks.VerifyEvent(payload, sign("whsec_old"))
+		require.NoError(t, err)
+		assert.Equal(t, "evt_1", event.ID)
+		assert.Equal(t, "whsec_old", secret)
+
+		_, secret, err = webhooks.VerifyEvent(payload, sign("whsec_new_1"))
+		require.NoError(t, err)
+		assert.Equal(t, "whsec_new_1", secret)
+	})
+
+	t.Run("should reject a second rotation while one is pending", func(t *testing.T) {
+		service, _, _, _ := setup(newConfig())
+
+		_, err := service.Rotate(context.Background())
+		require.NoError(t, err)
+		_, err = service.Rotate(context.Background())
+		assert.ErrorIs(t, err, webhooksecrets.ErrRotationInProgress)
+	})
+
+	t.Run("should delete the new endpoint when the rotation cannot be stored", func(t *testing.T) {
+		service, store, endpoints, _ := setup(newConfig())
+		store.err = errors.New("database unavailable")
+
+		_, err := service.Rotate(context.Background())
+		assert.Error(t, err)
+		assert.Equal(t, []string{"we_new_1"}, endpoints.deleted)
+		assert.Equal(t, []string{"whsec_old"}, service.WebhookSecrets())
+	})
+
+	t.Run("should only retire the old endpoint once deliveries with the new secret are confirmed", func(t *testing.T) {
+		config := newConfig()
+		config.GracePeriod = 0
+		service, _, endpoints, webhooks := setup(config)
+
+		rotation, err := service.Rotate(context.Background())
+		require.NoError(t, err)
+
+		// Deliveries with the old secret do not count
+		require.NoError(t, service.Observe(context.Background(), "whsec_old"))
+		require.NoError(t, service.Observe(context.Background(), "whsec_new_1"))
+
+		_, err = service.Complete(context.Background(), rotation.ID)
+		assert.ErrorIs(t, err, webhooksecrets.ErrNotConfirmed)
+		advanced, err := service.Advance(context.Background())
+		require.NoError(t, err)
+		assert.Nil(t, advanced)
+
+		require.NoError(t, service.Observe(context.Background(), "whsec_new_1"))
+		completed, err := service.Advance(context.Background())
+		require.NoError(t, err)
+		require.NotNil(t, completed)
+		assert.Equal(t, webhooksecrets.StatusCompleted, completed.Status)
+		assert.Equal(t, 2, completed.Deliveries)
+		assert.NotNil(t, completed.ConfirmedAt)
+		assert.Equal(t, []string{"we_old"}, endpoints.deleted)
+
+		_, _, err = webhooks.VerifyEvent(payload, sign("whsec_old"))
+		assert.Error(t, err)
+		_, _, err = webhooks.VerifyEvent(payload, sign("whsec_new_1"))
+		assert.NoError(t, err)
+	})
+
+	t.Run("should wait for the grace period before retiring automatically", func(t *testing.T) {
+		service, _, endpoints, _ := setup(newConfig())
+
+		rotation, err := service.Rotate(context.Background())
+		require.NoError(t, err)
+		require.NoError(t, service.Observe(context.Background(), "whsec_new_1"))
+		require.NoError(t, service.Observe(context.Background(), "whsec_new_1"))
+
+		advanced, err := service.Advance(context.Background())
+		require.NoError(t, err)
+		assert.Nil(t, advanced)
+
+		// Completing manually skips the rest of the grace period
+		completed, err := service.Complete(context.Background(), rotation.ID)
+		require.NoError(t, err)
+		assert.Equal(t, webhooksecrets.StatusCompleted, completed.Status)
+		assert.Equal(t, []string{"we_old"}, endpoints.deleted)
+
+		_, err = service.Complete(context.Background(), rotation.ID)
+		assert.ErrorIs(t, err, webhooksecrets.ErrNotPending)
+	})
+
+	t.Run("should keep the old secret when a rotation is cancelled", func(t *testing.T) {
+		service, _, endpoints, _ := setup(newConfig())
+
+		rotation, err := service.Rotate(context.Background())
+		require.NoError(t, err)
+
+		cancelled, err := service.Cancel(context.Background(), rotation.ID)
+		require.NoError(t, err)
+		assert.Equal(t, webhooksecrets.StatusCancelled, cancelled.Status)
+		assert.Equal(t, []string{"we_new_1"}, endpoints.deleted)
+		assert.Equal(t, []string{"whsec_old"}, service.WebhookSecrets())
+	})
+
+	t.Run("should pick up rotations made by other instances", func(t *testing.T) {
+		config := newConfig()
+		config.GracePeriod = 0
+		config.MinDeliveries = 1
+		service, store, endpoints, _ := setup(config)
+		cipher, err := tenantcredentials.NewCipher(make([]byte, 32))
+		require.NoError(t, err)
+		other := webhooksecrets.NewService(store, endpoints, cipher, config)
+
+		_, err = other.Rotate(context.Background())
+		require.NoError(t, err)
+		require.NoError(t, service.Refresh(context.Background()))
+		assert.Equal(t, []string{"whsec_old", "whsec_new_1"}, service.WebhookSecrets())
+
+		require.NoError(t, service.Observe(context.Background(), "whsec_new_1"))
+		_, err = other.Advance(context.Background())
+		require.NoError(t, err)
+
+		require.NoError(t, service.Refresh(context.Background()))
+		assert.Equal(t, []string{"whsec_new_1"}, service.WebhookSecrets())
+
+		// The next rotation starts from the new endpoint
+		rotation, err := service.Rotate(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "we_new_1", rotation.OldEndpointID)
+	})
+
+	t.Run("should not start rotations without encryption or an endpoint", func(t *testing.T) {
+		service := webhooksecrets.NewService(NewMockWebhookSecretStore(), NewMockWebhookEndpoints(), nil, newConfig())
+		_, err := service.Rotate(context.Background())
+		assert.ErrorIs(t, err, tenantcredentials.ErrEncryptionNotConfigured)
+
+		config := newConfig()
+		config.EndpointID = ""
+		service, _, _, _ = setup(config)
+		_, err = service.Rotate(context.Background())
+		assert.ErrorIs(t, err, webhooksecrets.ErrEndpointNotConfigured)
+	})
+}
+
+// MockWebhookSecretStore is a mock implementation of webhooksecrets.Store
+type MockWebhookSecretStore struct {
+	rotations map[string]*webhooksecrets.Rotation
+	err       error
+}
+
+// NewMockWebhookSecretStore creates a new mock webhook secret store
+func NewMockWebhookSecretStore() *MockWebhookSecretStore {
+	return &MockWebhookSecretStore{
+		rotations: make(map[string]*webhooksecrets.Rotation),
+	}
+}
+
+func (m *MockWebhookSecretStore) CreateWebhookSecretRotation(ctx context.Context, rotation *webhooksecrets.Rotation) (*webhooksecrets.Rotation, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	for _, existing := range m.rotations {
+		if existing.Status == webhooksecrets.StatusPending {
+			return nil, errors.New("duplicate key value violates unique constraint")
+		}
+	}
+	stored := *rotation
+	stored.Status = webhooksecrets.StatusPending
+	stored.CreatedAt = time.Now()
+	m.rotations[rotation.ID] = &stored
+	copied := stored
+	return &copied, nil
+}
+
+func (m *MockWebhookSecretStore) GetWebhookSecretRotation(ctx context.Context, id string) (*webhooksecrets.Rotation, error) {
+	rotation, ok := m.rotations[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *rotation
+	return &copied, nil
+}
+
+func (m *MockWebhookSecretStore) GetPendingWebhookSecretRotation(ctx context.Context) (*webhooksecrets.Rotation, error) {
+	for _, rotation := range m.rotations {
+		if rotation.Status == webhooksecrets.StatusPending {
+			copied := *rotation
+			return &copied, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *MockWebhookSecretStore) GetLatestCompletedWebhookSecretRotation(ctx context.Context) (*webhooksecrets.Rotation, error) {
+	var latest *webhooksecrets.Rotation
+	for _, rotation := range m.rotations {
+		if rotation.Status == webhooksecrets.StatusCompleted && (latest == nil || rotation.CompletedAt.After(*latest.CompletedAt)) {
+			latest = rotation
+		}
+	}
+	if latest == nil {
+		return nil, sql.ErrNoRows
+	}
+	copied := *latest
+	return &copied, nil
+}
+
+func (m *MockWebhookSecretStore) ListWebhookSecretRotations(ctx context.Context, limit int) ([]*webhooksecrets.Rotation, error) {
+	var rotations []*webhooksecrets.Rotation
+	for _, rotation := range m.rotations {
+		copied := *rotation
+		rotations = append(rotations, &copied)
+	}
+	sort.Slice(rotations, func(i, j int) bool { return rotations[i].CreatedAt.After(rotations[j].CreatedAt) })
+	if len(rotations) > limit {
+		rotations = rotations[:limit]
+	}
+	return rotations, nil
+}
+
+func (m *MockWebhookSecretStore) RecordWebhookSecretRotationDelivery(ctx context.Context, id string) (*webhooksecrets.Rotation, error) {
+	rotation, ok := m.rotations[id]
+	if !ok || rotation.Status != webhooksecrets.StatusPending {
+		return nil, sql.ErrNoRows
+	}
+	rotation.Deliveries++
+	if rotation.ConfirmedAt == nil {
+		now := time.Now()
+		rotation.ConfirmedAt = &now
+	}
+	copied := *rotation
+	return &copied, nil
+}
+
+func (m *MockWebhookSecretStore) FinishWebhookSecretRotation(ctx context.Context, rotation *webhooksecrets.Rotation) (*webhooksecrets.Rotation, error) {
+	stored, ok := m.rotations[rotation.ID]
+	if !ok || stored.Status != webhooksecrets.StatusPending {
+		return nil, sql.ErrNoRows
+	}
+	stored.Status = rotation.Status
+	stored.CompletedAt = rotation.CompletedAt
+	stored.CancelledAt = rotation.CancelledAt
+	copied := *stored
+	return &copied, nil
+}
+
+// MockWebhookEndpoints is a mock implementation of webhooksecrets.Endpoints
+type MockWebhookEndpoints struct {
+	cloned  []string
+	deleted []string
+}
+
+// NewMockWebhookEndpoints creates a new mock webhook endpoint manager
+func NewMockWebhookEndpoints() *MockWebhookEndpoints {
+	return &MockWebhookEndpoints{}
+}
+
+func (m *MockWebhookEndpoints) CloneWebhookEndpoint(ctx context.Context, endpointID string) (*stripe.WebhookEndpoint, error) {
+	m.cloned = append(m.cloned, endpointID)
+	suffix := string(rune('0' + len(m.cloned)))
+	return &stripe.WebhookEndpoint{
+		ID:     "we_new_" + suffix,
+		Secret: "whsec_new_" + suffix,
+	}, nil
+}
+
+func (m *MockWebhookEndpoints) DeleteWebhookEndpoint(ctx context.Context, endpointID string) error {
+	m.deleted = append(m.deleted, endpointID)
+	return nil
+}